@@ -8,14 +8,25 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
 	"github.com/innovabizdevops/innovabiz-iam/observability/constants"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/dedup"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/explain"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/fraudsignals"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/monitoring"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/pii"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/registries"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/reports"
+	"github.com/innovabizdevops/innovabiz-iam/src/bureau-credito/scheduling"
+	"github.com/innovabizdevops/innovabiz-iam/src/rules"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -59,6 +70,7 @@ const (
 	OrigemTelecom           OrigemRegistro = "telecom"
 	OrigemUtilidades        OrigemRegistro = "utilidades"
 	OrigemMicroFinanceiras  OrigemRegistro = "microfinanceiras"
+	OrigemRegistrosPublicos OrigemRegistro = "registros_publicos" // Cartórios de protesto e dívida ativa
 )
 
 // TipoRegistro define os diferentes tipos de registros de crédito
@@ -123,6 +135,11 @@ type ResultadoConsulta struct {
 	DataAnalise      time.Time      `json:"dataAnalise"`
 	RecomendacaoList []string       `json:"recomendacaoList,omitempty"`
 	RelatorioURL     *string        `json:"relatorioUrl,omitempty"`
+	RelatorioJobID   *string        `json:"relatorioJobId,omitempty"` // Job de geração do relatório detalhado
+	RiscoFraude      *fraudsignals.FraudRisk `json:"riscoFraude,omitempty"` // Sinais de fraude e de identidade sintética
+	CodigosMotivo    []explain.Reason `json:"codigosMotivo,omitempty"` // Motivos padronizados que reduziram o score, por ordem de impacto
+	ContribuicoesFatores []explain.FactorContribution `json:"contribuicoesFatores,omitempty"` // Contribuição de cada fator para o score
+	AvisoAcaoAdversa *explain.AdverseActionNotice `json:"avisoAcaoAdversa,omitempty"` // Principais motivos de ação adversa (FCRA), mercado americano
 	MetadadosConsulta map[string]interface{} `json:"metadadosConsulta,omitempty"`
 	TempoProcessamento int64         `json:"tempoProcessamento"`
 }
@@ -133,7 +150,7 @@ type RegrasCompliance struct {
 	Market        string   `json:"market"`
 	Description   string   `json:"description"`
 	Framework     []string `json:"framework"`
-	MandatoryFor  []string `json:"mandatoryFor"` // Tipos de consulta para os quais a regra é mandatória
+	MandatoryFor  []string `json:"mandatoryFor"` // Tipos de consulta para os quais a regra é mandatória (vazio aplica-a a todos)
 	Validate      func(*ConsultaCredito) (bool, string, error)
 }
 
@@ -143,7 +160,7 @@ type RegraAcesso struct {
 	Market       string   `json:"market"`
 	Description  string   `json:"description"`
 	TipoEntidade []string `json:"tipoEntidade"` // PF, PJ ou ambos
-	TipoConsulta []string `json:"tipoConsulta"` // Tipos de consulta permitidos
+	TipoConsulta []string `json:"tipoConsulta"` // Tipos de consulta permitidos (vazio aplica-a a todos)
 	MFAMinimo    string   `json:"mfaMinimo"`    // Nível mínimo de MFA necessário
 	Validate     func(*ConsultaCredito) (bool, string, error)
 }
@@ -160,6 +177,9 @@ type BureauCreditoConfig struct {
 	ValidadeConsentimento     map[string]int     `json:"validadeConsentimento"`    // Em dias, por finalidade
 	NotificacaoObrigatoria    map[string]bool    `json:"notificacaoObrigatoria"`   // Por mercado
 	CamposObrigatorios        map[string][]string `json:"camposObrigatorios"`      // Por tipo de consulta
+	MonitoringAPIAddr         string             `json:"monitoringApiAddr,omitempty"` // API de subscrições de monitorização (vazio desativa)
+	SchedulingAPIAddr         string             `json:"schedulingApiAddr,omitempty"` // API dos jobs agendados de consulta (vazio desativa)
+	RegistriesAPIAddr         string             `json:"registriesApiAddr,omitempty"` // API de operação dos conectores de registos públicos (vazio desativa)
 }
 
 // BureauCredito representa o serviço de Bureau de Crédito
@@ -167,9 +187,21 @@ type BureauCredito struct {
 	config              BureauCreditoConfig
 	observability       adapter.IAMObservability
 	logger              *zap.Logger
-	regrasCompliance    []RegrasCompliance
-	regrasAcesso        []RegraAcesso
+	regrasCompliance    *rules.Engine[*ConsultaCredito] // Motor de regras partilhado com o gateway de pagamentos
+	regrasAcesso        *rules.Engine[*ConsultaCredito]
 	consultasDiarias    map[string]int // Contador de consultas diárias por entidade
+	encryptor           *pii.FieldEncryptor // Criptografia de PII por mercado (opcional)
+	relatorios          *reports.ReportGenerator // Geração assíncrona de relatórios (opcional)
+	supressor           *dedup.Suppressor // Supressão de consultas duplicadas (opcional)
+	enriquecedorFraude  *fraudsignals.Enricher // Enriquecimento com sinais de fraude (opcional)
+	explicadorScore     *explain.Explainer // Motivos e contribuição dos fatores do score
+	monitor             *monitoring.Monitor // Monitorização contínua de documentos (opcional)
+	monitoringServer    *http.Server
+	agendador           *scheduling.Scheduler // Consultas recorrentes de carteiras (opcional)
+	schedulingServer    *http.Server
+	ingestaoRegistros   *registries.Ingestor // Ingestão de protestos e dívida ativa (opcional)
+	registrosPublicos   *registries.Index
+	registriesServer    *http.Server
 	mutex               sync.RWMutex
 	shutdown            chan struct{}
 	wg                  sync.WaitGroup
@@ -177,15 +209,76 @@ type BureauCredito struct {
 
 // NewBureauCredito cria uma nova instância do Bureau de Crédito
 func NewBureauCredito(config BureauCreditoConfig, obs adapter.IAMObservability, logger *zap.Logger) *BureauCredito {
+	regrasCompliance := rules.NewEngine[*ConsultaCredito]("bureau_credito_compliance")
+	regrasCompliance.SetTracer(obs.Tracer())
+	regrasAcesso := rules.NewEngine[*ConsultaCredito]("bureau_credito_acesso")
+	regrasAcesso.SetTracer(obs.Tracer())
+
 	return &BureauCredito{
 		config:           config,
 		observability:    obs,
 		logger:           logger,
-		regrasCompliance: []RegrasCompliance{},
-		regrasAcesso:     []RegraAcesso{},
+		regrasCompliance: regrasCompliance,
+		regrasAcesso:     regrasAcesso,
 		consultasDiarias: make(map[string]int),
+		explicadorScore:  explain.NewExplainer(explain.DefaultConfig(), explain.DefaultCatalog()),
 		shutdown:         make(chan struct{}),
 	}
+}
+
+// SetRegrasMetrics configura as métricas Prometheus das avaliações das regras de acesso e de compliance
+func (bc *BureauCredito) SetRegrasMetrics(metrics *rules.Metrics) {
+	bc.regrasCompliance.SetMetrics(metrics)
+	bc.regrasAcesso.SetMetrics(metrics)
+}
+
+// SetFieldEncryptor configura a criptografia de campos de PII nos registros retornados
+func (bc *BureauCredito) SetFieldEncryptor(encryptor *pii.FieldEncryptor) {
+	bc.encryptor = encryptor
+}
+
+// SetReportGenerator configura a geração assíncrona dos relatórios detalhados de consulta
+func (bc *BureauCredito) SetReportGenerator(generator *reports.ReportGenerator) {
+	bc.relatorios = generator
+}
+
+// SetConsultaSuppressor configura a supressão de consultas duplicadas ao provedor
+func (bc *BureauCredito) SetConsultaSuppressor(supressor *dedup.Suppressor) {
+	bc.supressor = supressor
+}
+
+// SetFraudSignalEnricher configura o enriquecimento das consultas com sinais de fraude e de identidade sintética
+func (bc *BureauCredito) SetFraudSignalEnricher(enriquecedor *fraudsignals.Enricher) {
+	bc.enriquecedorFraude = enriquecedor
+}
+
+// SetScoreExplainer substitui a explicação do score, por exemplo com o catálogo de motivos de um mercado
+func (bc *BureauCredito) SetScoreExplainer(explicador *explain.Explainer) {
+	bc.explicadorScore = explicador
+}
+
+// SetMonitor configura a monitorização contínua de documentos subscritos pelos credores
+func (bc *BureauCredito) SetMonitor(monitor *monitoring.Monitor) {
+	bc.monitor = monitor
+}
+
+// SetScheduler configura os jobs agendados de consulta das carteiras dos credores
+func (bc *BureauCredito) SetScheduler(agendador *scheduling.Scheduler) {
+	bc.agendador = agendador
+}
+
+// SetPublicRegistries configura a ingestão dos registos públicos e o índice consultado nas restrições
+func (bc *BureauCredito) SetPublicRegistries(ingestor *registries.Ingestor, index *registries.Index) {
+	bc.ingestaoRegistros = ingestor
+	bc.registrosPublicos = index
+}
+
+// ObterRelatorio retorna o estado do job de relatório e, quando concluído, a URL pré-assinada
+func (bc *BureauCredito) ObterRelatorio(ctx context.Context, jobID string) (*reports.ReportJob, error) {
+	if bc.relatorios == nil {
+		return nil, fmt.Errorf("geração de relatórios não configurada")
+	}
+	return bc.relatorios.GetJob(ctx, jobID)
 }// RealizarConsulta processa uma consulta ao Bureau de Crédito
 func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta ConsultaCredito) (*ResultadoConsulta, error) {
 	// Iniciar rastreamento com OpenTelemetry
@@ -195,7 +288,7 @@ func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta Consulta
 			attribute.String("tipo_consulta", string(consulta.TipoConsulta)),
 			attribute.String("finalidade", string(consulta.Finalidade)),
 			attribute.String("entidade_id", consulta.EntidadeID),
-			attribute.String("documento_cliente", consulta.DocumentoCliente),
+			attribute.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)),
 			attribute.String("market", consulta.MarketContext.Market),
 		),
 	)
@@ -207,14 +300,14 @@ func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta Consulta
 		zap.String("tipo_consulta", string(consulta.TipoConsulta)),
 		zap.String("finalidade", string(consulta.Finalidade)),
 		zap.String("entidade_id", consulta.EntidadeID),
-		zap.String("documento_cliente", consulta.DocumentoCliente),
+		zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)),
 		zap.String("market", consulta.MarketContext.Market))
 
 	// Registrar evento de auditoria para a consulta
 	bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 		"bureau_credito_consulta_iniciada",
 		fmt.Sprintf("Consulta %s iniciada para documento %s (tipo: %s, finalidade: %s)",
-			consulta.ConsultaID, consulta.DocumentoCliente, consulta.TipoConsulta, consulta.Finalidade))
+			consulta.ConsultaID, adapter.MaskPII(consulta.DocumentoCliente), consulta.TipoConsulta, consulta.Finalidade))
 
 	// Registrar métrica de tentativa de consulta
 	bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_consultas_total", 
@@ -287,7 +380,7 @@ func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta Consulta
 	if err := bc.verificarConsentimento(ctx, consulta); err != nil {
 		bc.logger.Error("Falha na verificação de consentimento",
 			zap.String("consulta_id", consulta.ConsultaID),
-			zap.String("documento_cliente", consulta.DocumentoCliente),
+			zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)),
 			zap.Error(err))
 		
 		// Registrar evento de segurança para falha de consentimento
@@ -317,8 +410,8 @@ func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta Consulta
 	// Iniciar tempo de processamento
 	startTime := time.Now()
 
-	// Processar a consulta (simulada para este exemplo)
-	resultado, err := bc.processarConsulta(ctx, consulta)
+	// Processar a consulta (simulada para este exemplo), reutilizando o resultado de uma idêntica recente
+	resultado, err := bc.executarConsulta(ctx, consulta)
 	if err != nil {
 		bc.logger.Error("Erro no processamento da consulta",
 			zap.String("consulta_id", consulta.ConsultaID),
@@ -338,7 +431,7 @@ func (bc *BureauCredito) RealizarConsulta(ctx context.Context, consulta Consulta
 	bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 		"bureau_credito_consulta_concluida",
 		fmt.Sprintf("Consulta %s concluída para documento %s (tipo: %s, tempo: %dms)",
-			consulta.ConsultaID, consulta.DocumentoCliente, consulta.TipoConsulta, processTime))
+			consulta.ConsultaID, adapter.MaskPII(consulta.DocumentoCliente), consulta.TipoConsulta, processTime))
 
 	// Registrar métricas de sucesso e tempo de processamento
 	bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_consultas_sucesso", 
@@ -457,55 +550,31 @@ func (bc *BureauCredito) verificarRegrasAcesso(ctx context.Context, consulta Con
 	ctx, span := bc.observability.Tracer().Start(ctx, "verificar_regras_acesso")
 	defer span.End()
 
-	// Verificar regras de acesso aplicáveis
-	for _, regra := range bc.regrasAcesso {
-		// Verificar se a regra se aplica ao mercado atual ou é global
-		if regra.Market == constants.MarketGlobal || regra.Market == consulta.MarketContext.Market {
-			// Verificar se a regra se aplica ao tipo de entidade
-			aplicaTipoEntidade := false
-			for _, tipoEntidade := range regra.TipoEntidade {
-				if tipoEntidade == consulta.TipoEntidade {
-					aplicaTipoEntidade = true
-					break
-				}
-			}
-
-			// Verificar se a regra se aplica ao tipo de consulta
-			aplicaTipoConsulta := false
-			for _, tipoConsulta := range regra.TipoConsulta {
-				if tipoConsulta == string(consulta.TipoConsulta) {
-					aplicaTipoConsulta = true
-					break
-				}
-			}
-
-			if aplicaTipoEntidade && aplicaTipoConsulta {
-				// Aplicar regra de acesso
-				acessoPermitido, mensagem, err := regra.Validate(&consulta)
-				if err != nil {
-					bc.logger.Error("Erro ao validar regra de acesso",
-						zap.String("regra_id", regra.ID),
-						zap.String("consulta_id", consulta.ConsultaID),
-						zap.Error(err))
-					return fmt.Errorf("erro ao validar regra de acesso %s: %w", regra.ID, err)
-				}
-
-				if !acessoPermitido {
-					// Registrar evento de segurança para acesso negado
-					bc.observability.TraceSecurityEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
-						constants.SecurityEventSeverityMedium, "bureau_credito_access_denied",
-						fmt.Sprintf("Regra de acesso %s negou consulta %s: %s", 
-							regra.ID, consulta.ConsultaID, mensagem))
-					
-					return fmt.Errorf("acesso negado - %s: %s", regra.ID, mensagem)
-				}
+	// Verificar regras de acesso aplicáveis ao mercado, ao tipo de consulta e ao tipo de entidade
+	avaliacao := bc.regrasAcesso.Evaluate(ctx, consulta.MarketContext.Market, string(consulta.TipoConsulta), &consulta)
+	for _, resultado := range avaliacao.Outcomes {
+		if resultado.Err != nil {
+			bc.logger.Error("Erro ao validar regra de acesso",
+				zap.String("regra_id", resultado.RuleID),
+				zap.String("consulta_id", consulta.ConsultaID),
+				zap.Error(resultado.Err))
+			return fmt.Errorf("erro ao validar regra de acesso %s: %w", resultado.RuleID, resultado.Err)
+		}
 
-				// Registrar evento de auditoria para acesso permitido
-				bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
-					fmt.Sprintf("access_rule_%s_verified", regra.ID),
-					fmt.Sprintf("Regra de acesso %s verificada: %s", regra.ID, mensagem))
-			}
+		if !resultado.Passed {
+			// Registrar evento de segurança para acesso negado
+			bc.observability.TraceSecurityEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
+				constants.SecurityEventSeverityMedium, "bureau_credito_access_denied",
+				fmt.Sprintf("Regra de acesso %s negou consulta %s: %s", 
+					resultado.RuleID, consulta.ConsultaID, resultado.Message))
+			
+			return fmt.Errorf("acesso negado - %s: %s", resultado.RuleID, resultado.Message)
 		}
+
+		// Registrar evento de auditoria para acesso permitido
+		bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
+			fmt.Sprintf("access_rule_%s_verified", resultado.RuleID),
+			fmt.Sprintf("Regra de acesso %s verificada: %s", resultado.RuleID, resultado.Message))
 	}
 
 	return nil
@@ -574,7 +643,7 @@ func (bc *BureauCredito) verificarConsentimento(ctx context.Context, consulta Co
 		bc.observability.TraceSecurityEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 			constants.SecurityEventSeverityHigh, "bureau_credito_invalid_consent",
 			fmt.Sprintf("Consentimento inválido na consulta %s para documento %s", 
-				consulta.ConsultaID, consulta.DocumentoCliente))
+				consulta.ConsultaID, adapter.MaskPII(consulta.DocumentoCliente)))
 		
 		return fmt.Errorf("consentimento inválido ou expirado")
 	}
@@ -583,7 +652,7 @@ func (bc *BureauCredito) verificarConsentimento(ctx context.Context, consulta Co
 	bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 		"consent_verified",
 		fmt.Sprintf("Consentimento verificado para consulta %s, documento %s", 
-			consulta.ConsultaID, consulta.DocumentoCliente))
+			consulta.ConsultaID, adapter.MaskPII(consulta.DocumentoCliente)))
 
 	return nil
 }
@@ -593,49 +662,280 @@ func (bc *BureauCredito) verificarCompliance(ctx context.Context, consulta Consu
 	ctx, span := bc.observability.Tracer().Start(ctx, "verificar_compliance")
 	defer span.End()
 
-	// Verificar regras de compliance aplicáveis
-	for _, regra := range bc.regrasCompliance {
-		// Verificar se a regra se aplica ao mercado atual ou é global
-		if regra.Market == constants.MarketGlobal || regra.Market == consulta.MarketContext.Market {
-			// Verificar se a regra é obrigatória para este tipo de consulta
-			aplicaTipoConsulta := false
-			for _, tipoConsulta := range regra.MandatoryFor {
-				if tipoConsulta == string(consulta.TipoConsulta) {
-					aplicaTipoConsulta = true
-					break
-				}
-			}
+	// Verificar regras de compliance aplicáveis ao mercado e ao tipo de consulta
+	avaliacao := bc.regrasCompliance.Evaluate(ctx, consulta.MarketContext.Market, string(consulta.TipoConsulta), &consulta)
+	for _, resultado := range avaliacao.Outcomes {
+		if resultado.Err != nil {
+			bc.logger.Error("Erro ao validar regra de compliance",
+				zap.String("regra_id", resultado.RuleID),
+				zap.String("consulta_id", consulta.ConsultaID),
+				zap.Error(resultado.Err))
+			return fmt.Errorf("erro ao validar regra de compliance %s: %w", resultado.RuleID, resultado.Err)
+		}
 
-			if aplicaTipoConsulta {
-				// Aplicar regra de compliance
-				conforme, mensagem, err := regra.Validate(&consulta)
-				if err != nil {
-					bc.logger.Error("Erro ao validar regra de compliance",
-						zap.String("regra_id", regra.ID),
-						zap.String("consulta_id", consulta.ConsultaID),
-						zap.Error(err))
-					return fmt.Errorf("erro ao validar regra de compliance %s: %w", regra.ID, err)
-				}
+		if !resultado.Passed {
+			// Registrar evento de segurança para compliance violado
+			bc.observability.TraceSecurityEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
+				constants.SecurityEventSeverityHigh, "bureau_credito_compliance_violation",
+				fmt.Sprintf("Regra de compliance %s violada na consulta %s: %s", 
+					resultado.RuleID, consulta.ConsultaID, resultado.Message))
+			
+			return fmt.Errorf("violação de compliance - %s: %s", resultado.RuleID, resultado.Message)
+		}
 
-				if !conforme {
-					// Registrar evento de segurança para compliance violado
-					bc.observability.TraceSecurityEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
-						constants.SecurityEventSeverityHigh, "bureau_credito_compliance_violation",
-						fmt.Sprintf("Regra de compliance %s violada na consulta %s: %s", 
-							regra.ID, consulta.ConsultaID, mensagem))
-					
-					return fmt.Errorf("violação de compliance - %s: %s", regra.ID, mensagem)
-				}
+		// Registrar evento de auditoria para compliance verificado
+		bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
+			fmt.Sprintf("compliance_rule_%s_verified", resultado.RuleID),
+			fmt.Sprintf("Regra de compliance %s verificada: %s", resultado.RuleID, resultado.Message))
+	}
 
-				// Registrar evento de auditoria para compliance verificado
-				bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
-					fmt.Sprintf("compliance_rule_%s_verified", regra.ID),
-					fmt.Sprintf("Regra de compliance %s verificada: %s", regra.ID, mensagem))
-			}
+	return nil
+}
+
+// executarConsulta processa a consulta ou, havendo uma idêntica (mesmo solicitante, documento,
+// tipo e finalidade) em curso ou concluída dentro da janela, devolve uma cópia do seu resultado
+func (bc *BureauCredito) executarConsulta(ctx context.Context, consulta ConsultaCredito) (*ResultadoConsulta, error) {
+	if bc.supressor == nil {
+		return bc.processarConsulta(ctx, consulta)
+	}
+
+	key := dedup.Key{
+		TenantID:   consulta.SolicitanteID,
+		Documento:  consulta.DocumentoCliente,
+		Tipo:       string(consulta.TipoConsulta),
+		Finalidade: string(consulta.Finalidade),
+	}
+	result, err := bc.supressor.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return bc.processarConsulta(ctx, consulta)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// O resultado guardado é partilhado pelas duplicadas: cada consulta recebe a sua cópia
+	original := result.Value.(*ResultadoConsulta)
+	resultado := *original
+	if !result.Suppressed() {
+		return &resultado, nil
+	}
+
+	resultado.ConsultaID = consulta.ConsultaID
+	resultado.MetadadosConsulta = make(map[string]interface{}, len(original.MetadadosConsulta)+3)
+	for chave, valor := range original.MetadadosConsulta {
+		resultado.MetadadosConsulta[chave] = valor
+	}
+	resultado.MetadadosConsulta["suprimida"] = true
+	resultado.MetadadosConsulta["consultaOriginalId"] = original.ConsultaID
+	resultado.MetadadosConsulta["origemResultado"] = result.Outcome
+
+	bc.logger.Info("Consulta duplicada suprimida",
+		zap.String("consulta_id", consulta.ConsultaID),
+		zap.String("consulta_original_id", original.ConsultaID),
+		zap.String("origem", result.Outcome),
+		zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)))
+
+	bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
+		"bureau_credito_consulta_suprimida",
+		fmt.Sprintf("Consulta %s respondida com o resultado da consulta %s (%s)",
+			consulta.ConsultaID, original.ConsultaID, result.Outcome))
+
+	// Registrar métrica de consultas poupadas ao provedor
+	bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_consultas_suprimidas",
+		string(consulta.TipoConsulta), 1)
+
+	return &resultado, nil
+}
+
+// ConsultarMonitorizacao consulta o score e as restrições de um documento monitorizado
+// O consentimento é verificado pelo monitor antes de cada consulta; as consultas contam
+// para o limite diário do credor
+func (bc *BureauCredito) ConsultarMonitorizacao(ctx context.Context, subscription *monitoring.Subscription) (*monitoring.Snapshot, error) {
+	marketContext := adapter.MarketContext{
+		Market:     subscription.Market,
+		TenantType: bc.config.TenantType,
+	}
+	consulta := ConsultaCredito{
+		// O prefixo estável mantém os identificadores das restrições simuladas entre consultas
+		ConsultaID:       "MON" + subscription.ID,
+		Finalidade:       FinalidadeRevisaoLimites,
+		EntidadeID:       subscription.TenantID,
+		TipoEntidade:     subscription.TipoEntidade,
+		DocumentoCliente: subscription.Documento,
+		UsuarioID:        "monitoring:" + subscription.ID,
+		DataConsulta:     time.Now(),
+		ConsentimentoID:  subscription.ConsentimentoID,
+		SolicitanteID:    subscription.TenantID,
+		MarketContext:    marketContext,
+	}
+
+	score, restricoes, err := bc.consultarScoreRestricoes(ctx, consulta)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &monitoring.Snapshot{Score: score, ConsultadoEm: time.Now()}
+	for _, restricao := range restricoes {
+		snapshot.Restricoes = append(snapshot.Restricoes, monitoring.Restricao{
+			ID:             restricao.RegistroID,
+			Tipo:           string(restricao.TipoRegistro),
+			Valor:          restricao.Valor,
+			FonteNome:      restricao.FonteNome,
+			DataOcorrencia: restricao.DataOcorrencia,
+		})
+	}
+
+	bc.observability.TraceAuditEvent(ctx, marketContext, consulta.UsuarioID,
+		"bureau_credito_monitorizacao_consulta",
+		fmt.Sprintf("Consulta de monitorização da subscrição %s para documento %s",
+			subscription.ID, adapter.MaskPII(subscription.Documento)))
+	bc.observability.RecordMetric(marketContext, "bureau_credito_consultas_monitorizacao", "monitorizacao", 1)
+
+	return snapshot, nil
+}
+
+// ConsultarCarteira consulta o score e as restrições de um documento da carteira de um job agendado
+// O consentimento é verificado pelo agendador antes de cada execução; as consultas contam
+// para o limite diário do credor
+func (bc *BureauCredito) ConsultarCarteira(ctx context.Context, job *scheduling.Job, item *scheduling.JobItem) (*scheduling.Snapshot, error) {
+	marketContext := adapter.MarketContext{
+		Market:     job.Market,
+		TenantType: bc.config.TenantType,
+	}
+	consulta := ConsultaCredito{
+		// O prefixo estável mantém os identificadores das restrições simuladas entre execuções
+		ConsultaID:       "JOB" + item.ID,
+		Finalidade:       FinalidadeRevisaoLimites,
+		EntidadeID:       job.TenantID,
+		TipoEntidade:     item.TipoEntidade,
+		DocumentoCliente: item.Documento,
+		UsuarioID:        "scheduling:" + job.ID,
+		DataConsulta:     time.Now(),
+		ConsentimentoID:  item.ConsentimentoID,
+		SolicitanteID:    job.TenantID,
+		MarketContext:    marketContext,
+	}
+
+	score, restricoes, err := bc.consultarScoreRestricoes(ctx, consulta)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &scheduling.Snapshot{Score: score, ConsultadoEm: time.Now()}
+	for _, restricao := range restricoes {
+		snapshot.Restricoes = append(snapshot.Restricoes, scheduling.Restricao{
+			ID:             restricao.RegistroID,
+			Tipo:           string(restricao.TipoRegistro),
+			Valor:          restricao.Valor,
+			FonteNome:      restricao.FonteNome,
+			DataOcorrencia: restricao.DataOcorrencia,
+		})
+	}
+
+	bc.observability.TraceAuditEvent(ctx, marketContext, consulta.UsuarioID,
+		"bureau_credito_consulta_agendada",
+		fmt.Sprintf("Consulta agendada do job %s para documento %s",
+			job.ID, adapter.MaskPII(item.Documento)))
+	bc.observability.RecordMetric(marketContext, "bureau_credito_consultas_agendadas", "agendada", 1)
+
+	return snapshot, nil
+}
+
+// consultarScoreRestricoes executa as consultas de score e de restrições de um documento,
+// contando-as para o limite diário do credor
+func (bc *BureauCredito) consultarScoreRestricoes(ctx context.Context, consulta ConsultaCredito) (*int, []RegistroCredito, error) {
+	var score *int
+	var restricoes []RegistroCredito
+	for _, tipo := range []TipoConsulta{ConsultaScore, ConsultaRestricoes} {
+		consulta.TipoConsulta = tipo
+		resultado, err := bc.processarConsulta(ctx, consulta)
+		if err != nil {
+			return nil, nil, err
 		}
+		bc.incrementarConsultasDiarias(consulta.EntidadeID)
+
+		if resultado.ScoreCredito != nil {
+			value := *resultado.ScoreCredito
+			score = &value
+		}
+		restricoes = append(restricoes, resultado.RestricoesList...)
 	}
+	return score, restricoes, nil
+}
 
-	return nil
+// VerificarConsentimento valida o consentimento do titular para a monitorização e as consultas agendadas
+func (bc *BureauCredito) VerificarConsentimento(ctx context.Context, market, documento, consentimentoID string) (bool, error) {
+	marketContext := adapter.MarketContext{Market: market, TenantType: bc.config.TenantType}
+	return bc.observability.ValidateConsent(ctx, marketContext, documento, consentimentoID)
+}
+
+// startMonitoringAPI inicia a API de subscrições de monitorização quando configurada
+func (bc *BureauCredito) startMonitoringAPI() {
+	if bc.monitor == nil || bc.config.MonitoringAPIAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	monitoring.NewHandler(bc.monitor).Register(mux)
+	bc.monitoringServer = &http.Server{
+		Addr:              bc.config.MonitoringAPIAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+		bc.logger.Info("API de monitorização iniciada", zap.String("addr", bc.config.MonitoringAPIAddr))
+		if err := bc.monitoringServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			bc.logger.Error("Falha na API de monitorização", zap.Error(err))
+		}
+	}()
+}
+
+// startSchedulingAPI inicia a API dos jobs agendados de consulta quando configurada
+func (bc *BureauCredito) startSchedulingAPI() {
+	if bc.agendador == nil || bc.config.SchedulingAPIAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	scheduling.NewHandler(bc.agendador).Register(mux)
+	bc.schedulingServer = &http.Server{
+		Addr:              bc.config.SchedulingAPIAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+		bc.logger.Info("API de consultas agendadas iniciada", zap.String("addr", bc.config.SchedulingAPIAddr))
+		if err := bc.schedulingServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			bc.logger.Error("Falha na API de consultas agendadas", zap.Error(err))
+		}
+	}()
+}
+
+// startRegistriesAPI inicia a API de operação dos conectores de registos públicos quando configurada
+func (bc *BureauCredito) startRegistriesAPI() {
+	if bc.ingestaoRegistros == nil || bc.config.RegistriesAPIAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	registries.NewHandler(bc.ingestaoRegistros).Register(mux)
+	bc.registriesServer = &http.Server{
+		Addr:              bc.config.RegistriesAPIAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+		bc.logger.Info("API de registos públicos iniciada", zap.String("addr", bc.config.RegistriesAPIAddr))
+		if err := bc.registriesServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			bc.logger.Error("Falha na API de registos públicos", zap.Error(err))
+		}
+	}()
 }
 
 // processarConsulta simula o processamento real de uma consulta
@@ -647,7 +947,7 @@ func (bc *BureauCredito) processarConsulta(ctx context.Context, consulta Consult
 	bc.logger.Info("Processando consulta",
 		zap.String("consulta_id", consulta.ConsultaID),
 		zap.String("tipo", string(consulta.TipoConsulta)),
-		zap.String("documento_cliente", consulta.DocumentoCliente))
+		zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)))
 	
 	// Simular tempo de processamento
 	time.Sleep(100 * time.Millisecond)
@@ -700,9 +1000,8 @@ func (bc *BureauCredito) processarConsulta(ctx context.Context, consulta Consult
 			"Considerar limite reduzido para primeiras operações",
 		}
 		
-		// URL para relatório detalhado
-		relatorioURL := fmt.Sprintf("https://bureau.innovabiz.com/reports/%s", consulta.ConsultaID)
-		resultado.RelatorioURL = &relatorioURL
+		// Relatório detalhado gerado em background; a URL é obtida com ObterRelatorio
+		bc.solicitarRelatorio(ctx, consulta, resultado)
 		
 	case ConsultaScore:
 		// Gerar score simulado
@@ -745,6 +1044,22 @@ func (bc *BureauCredito) processarConsulta(ctx context.Context, consulta Consult
 		resultado.MetadadosConsulta["tempoMedioRelacionamento"] = "2.5 anos"
 	}
 
+	// Protestos e inscrições em dívida ativa ingeridos dos registos públicos
+	switch consulta.TipoConsulta {
+	case ConsultaCompleta, ConsultaBasica, ConsultaRestricoes:
+		resultado.RestricoesList = append(resultado.RestricoesList, bc.restricoesRegistrosPublicos(consulta)...)
+	}
+
+	// Motivos do score calculados com o histórico consultado, mesmo quando não é retornado
+	if resultado.ScoreCredito != nil {
+		registros, restricoes := resultado.RegistrosCredito, resultado.RestricoesList
+		if consulta.TipoConsulta == ConsultaScore {
+			registros = bc.gerarRegistrosSimulados(consulta, 5)
+			restricoes = append(bc.gerarRestricoesSimuladas(consulta, 2), bc.restricoesRegistrosPublicos(consulta)...)
+		}
+		bc.explicarScore(ctx, consulta, resultado, registros, restricoes)
+	}
+
 	// Registrar métricas específicas
 	if resultado.ScoreCredito != nil {
 		bc.observability.RecordHistogram(consulta.MarketContext, "bureau_credito_score", 
@@ -754,9 +1069,242 @@ func (bc *BureauCredito) processarConsulta(ctx context.Context, consulta Consult
 	bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_registros_retornados", 
 		string(consulta.TipoConsulta), float64(len(resultado.RegistrosCredito) + len(resultado.RestricoesList)))
 	
+	// Sinais de fraude avaliados com os registros ainda em claro
+	bc.enriquecerRiscoFraude(ctx, consulta, resultado)
+	
+	// Cifrar PII dos registros antes de armazenar ou retornar
+	if err := bc.protegerResultado(consulta, resultado); err != nil {
+		return nil, fmt.Errorf("falha ao cifrar dados pessoais: %w", err)
+	}
+	
 	return resultado, nil
 }
 
+// explicarScore acrescenta ao resultado os códigos de motivo, a contribuição dos fatores e,
+// no mercado americano, o aviso com os principais motivos de ação adversa
+func (bc *BureauCredito) explicarScore(ctx context.Context, consulta ConsultaCredito, resultado *ResultadoConsulta, registros, restricoes []RegistroCredito) {
+	if bc.explicadorScore == nil {
+		return
+	}
+	
+	_, span := bc.observability.Tracer().Start(ctx, "explicar_score")
+	defer span.End()
+	
+	converter := func(lista []RegistroCredito) []explain.Record {
+		convertidos := make([]explain.Record, 0, len(lista))
+		for _, registro := range lista {
+			convertidos = append(convertidos, explain.Record{
+				Tipo:           string(registro.TipoRegistro),
+				Limite:         numeroDetalhe(registro.Detalhes, "limiteCredito"),
+				Saldo:          numeroDetalhe(registro.Detalhes, "saldoUtilizado"),
+				DiasAtraso:     int(numeroDetalhe(registro.Detalhes, "diasAtraso")),
+				DataOcorrencia: registro.DataOcorrencia,
+			})
+		}
+		return convertidos
+	}
+	
+	explicacao := bc.explicadorScore.Explain(explain.Input{
+		Market:       consulta.MarketContext.Market,
+		Score:        *resultado.ScoreCredito,
+		Records:      converter(registros),
+		Restrictions: converter(restricoes),
+	})
+	resultado.CodigosMotivo = explicacao.Reasons
+	resultado.ContribuicoesFatores = explicacao.Factors
+	resultado.AvisoAcaoAdversa = explicacao.AdverseAction
+	
+	span.SetAttributes(
+		attribute.Int("score.motivos", len(explicacao.Reasons)),
+		attribute.Bool("score.acao_adversa", explicacao.AdverseAction != nil),
+	)
+	for _, motivo := range explicacao.Reasons {
+		bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_motivos_score", motivo.Code, 1)
+	}
+}
+
+// numeroDetalhe retorna o valor numérico de um detalhe do registro, ou zero quando ausente
+func numeroDetalhe(detalhes map[string]interface{}, chave string) float64 {
+	switch valor := detalhes[chave].(type) {
+	case float64:
+		return valor
+	case int:
+		return float64(valor)
+	case int64:
+		return float64(valor)
+	default:
+		return 0
+	}
+}
+
+// enriquecerRiscoFraude acrescenta ao resultado a secção de risco de fraude
+// Os contactos avaliados pelos provedores vêm dos parâmetros da consulta (telefone, email, dispositivoId, ip)
+func (bc *BureauCredito) enriquecerRiscoFraude(ctx context.Context, consulta ConsultaCredito, resultado *ResultadoConsulta) {
+	if bc.enriquecedorFraude == nil {
+		return
+	}
+	
+	ctx, span := bc.observability.Tracer().Start(ctx, "enriquecer_risco_fraude")
+	defer span.End()
+	
+	parametro := func(nome string) string {
+		valor, _ := consulta.Parametros[nome].(string)
+		return valor
+	}
+	
+	// As heurísticas de identidade sintética só se aplicam às consultas que retornam o histórico
+	var registros []fraudsignals.CreditRecord
+	switch consulta.TipoConsulta {
+	case ConsultaCompleta, ConsultaHistorico, ConsultaRelacionamento:
+		registros = make([]fraudsignals.CreditRecord, 0, len(resultado.RegistrosCredito)+len(resultado.RestricoesList))
+		for _, lista := range [][]RegistroCredito{resultado.RegistrosCredito, resultado.RestricoesList} {
+			for _, registro := range lista {
+				registros = append(registros, fraudsignals.CreditRecord{
+					Documento:      registro.DocumentoCliente,
+					Tipo:           string(registro.TipoRegistro),
+					Origem:         string(registro.OrigemRegistro),
+					Valor:          registro.Valor,
+					DataOcorrencia: registro.DataOcorrencia,
+					DataInclusao:   registro.DataInclusao,
+				})
+			}
+		}
+	}
+	
+	risco := bc.enriquecedorFraude.Enrich(ctx, fraudsignals.Request{
+		ConsultaID: consulta.ConsultaID,
+		Subject: fraudsignals.Subject{
+			TenantID:      consulta.SolicitanteID,
+			Market:        consulta.MarketContext.Market,
+			Documento:     consulta.DocumentoCliente,
+			TipoEntidade:  consulta.TipoEntidade,
+			Nome:          consulta.NomeCliente,
+			Telefone:      parametro("telefone"),
+			Email:         parametro("email"),
+			DispositivoID: parametro("dispositivoId"),
+			IP:            parametro("ip"),
+		},
+		Records: registros,
+	})
+	resultado.RiscoFraude = risco
+	
+	span.SetAttributes(
+		attribute.Int("fraude.score", risco.Score),
+		attribute.String("fraude.nivel", risco.Level),
+		attribute.Bool("fraude.identidade_sintetica", risco.SyntheticIdentity),
+	)
+	bc.observability.RecordHistogram(consulta.MarketContext, "bureau_credito_risco_fraude_score",
+		float64(risco.Score), string(consulta.TipoConsulta))
+	bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_risco_fraude", risco.Level, 1)
+	
+	if risco.SyntheticIdentity {
+		resultado.RecomendacaoList = append(resultado.RecomendacaoList,
+			"Validar a identidade do cliente: histórico com indícios de identidade sintética")
+	}
+	if risco.Level == fraudsignals.LevelHigh || risco.Level == fraudsignals.LevelCritical {
+		bc.logger.Warn("Risco de fraude elevado na consulta",
+			zap.String("consulta_id", consulta.ConsultaID),
+			zap.Int("score", risco.Score),
+			zap.String("nivel", risco.Level),
+			zap.Bool("identidade_sintetica", risco.SyntheticIdentity))
+	}
+	if len(risco.FailedProviders) > 0 {
+		bc.logger.Warn("Provedores de sinais de fraude indisponíveis",
+			zap.String("consulta_id", consulta.ConsultaID),
+			zap.Strings("provedores", risco.FailedProviders))
+	}
+}
+
+// solicitarRelatorio submete a geração do relatório detalhado antes da cifragem do resultado
+// Falhas não interrompem a consulta: o resultado segue sem relatório
+func (bc *BureauCredito) solicitarRelatorio(ctx context.Context, consulta ConsultaCredito, resultado *ResultadoConsulta) {
+	if bc.relatorios == nil {
+		return
+	}
+	
+	data := reports.ReportData{
+		ConsultaID:    consulta.ConsultaID,
+		TenantID:      consulta.SolicitanteID,
+		Market:        consulta.MarketContext.Market,
+		TipoConsulta:  string(consulta.TipoConsulta),
+		Finalidade:    string(consulta.Finalidade),
+		Documento:     adapter.MaskPII(consulta.DocumentoCliente),
+		NomeCliente:   consulta.NomeCliente,
+		DataConsulta:  consulta.DataConsulta,
+		Score:         resultado.ScoreCredito,
+		Registros:     registrosRelatorio(resultado.RegistrosCredito),
+		Restricoes:    registrosRelatorio(resultado.RestricoesList),
+		Recomendacoes: resultado.RecomendacaoList,
+	}
+	if resultado.FaixaRisco != nil {
+		data.FaixaRisco = *resultado.FaixaRisco
+	}
+	
+	job, err := bc.relatorios.Submit(ctx, data, reports.FormatPDF)
+	if err != nil {
+		bc.logger.Warn("Falha ao solicitar relatório detalhado",
+			zap.String("consulta_id", consulta.ConsultaID),
+			zap.Error(err))
+		return
+	}
+	resultado.RelatorioJobID = &job.JobID
+}
+
+// registrosRelatorio converte os registros de crédito para o formato do relatório
+func registrosRelatorio(registros []RegistroCredito) []reports.ReportRecord {
+	records := make([]reports.ReportRecord, 0, len(registros))
+	for _, r := range registros {
+		records = append(records, reports.ReportRecord{
+			Tipo:           string(r.TipoRegistro),
+			Origem:         string(r.OrigemRegistro),
+			Fonte:          r.FonteNome,
+			Valor:          r.Valor,
+			DataOcorrencia: r.DataOcorrencia,
+		})
+	}
+	return records
+}
+
+// protegerResultado cifra o documento e o nome do cliente com a chave ativa do mercado
+func (bc *BureauCredito) protegerResultado(consulta ConsultaCredito, resultado *ResultadoConsulta) error {
+	if bc.encryptor == nil {
+		return nil
+	}
+	
+	market := consulta.MarketContext.Market
+	for _, registros := range [][]RegistroCredito{resultado.RegistrosCredito, resultado.RestricoesList} {
+		for i := range registros {
+			documento, err := bc.encryptor.Encrypt(market, pii.FieldDocumentoCliente, registros[i].DocumentoCliente)
+			if err != nil {
+				return err
+			}
+			registros[i].DocumentoCliente = documento
+			
+			if registros[i].Detalhes == nil {
+				registros[i].Detalhes = make(map[string]interface{})
+			}
+			registros[i].Detalhes[pii.MetadataKeyID] = pii.KeyIDOf(documento)
+		}
+	}
+	
+	nome, err := bc.encryptor.Encrypt(market, pii.FieldNomeCliente, consulta.NomeCliente)
+	if err != nil {
+		return err
+	}
+	if nome != "" {
+		resultado.MetadadosConsulta["nomeCliente"] = nome
+	}
+	
+	documento, err := bc.encryptor.Encrypt(market, pii.FieldDocumentoCliente, consulta.DocumentoCliente)
+	if err != nil {
+		return err
+	}
+	resultado.MetadadosConsulta["documentoCliente"] = documento
+	resultado.MetadadosConsulta[pii.MetadataKeyID] = pii.KeyIDOf(documento)
+	
+	return nil
+}
+
 // gerarRegistrosSimulados gera registros simulados para teste
 func (bc *BureauCredito) gerarRegistrosSimulados(consulta ConsultaCredito, quantidade int) []RegistroCredito {
 	registros := make([]RegistroCredito, 0, quantidade)
@@ -808,6 +1356,8 @@ func (bc *BureauCredito) gerarRegistrosSimulados(consulta ConsultaCredito, quant
 				"tipoOperacao":   "Simulada",
 				"situacaoAtual":  "Regular",
 				"numContrato":    fmt.Sprintf("CONT%d", 10000+i),
+				"limiteCredito":  5000.0 + float64(i*1000),
+				"saldoUtilizado": 1000.0 + float64(i*500),
 			},
 			MarketContext: consulta.MarketContext,
 			DocumentosRelacionados: []string{
@@ -872,7 +1422,52 @@ func (bc *BureauCredito) gerarRestricoesSimuladas(consulta ConsultaCredito, quan
 	}
 	
 	return restricoes
-}// processarNotificacoes processa notificações obrigatórias por mercado
+}
+
+// restricoesRegistrosPublicos converte os registos públicos ativos do documento em restrições
+func (bc *BureauCredito) restricoesRegistrosPublicos(consulta ConsultaCredito) []RegistroCredito {
+	if bc.registrosPublicos == nil {
+		return nil
+	}
+
+	registros := bc.registrosPublicos.Restricoes(consulta.MarketContext.Market, consulta.DocumentoCliente)
+	restricoes := make([]RegistroCredito, 0, len(registros))
+	for _, registro := range registros {
+		tipoRegistro := RegistroProtesto
+		if registro.Fonte == registries.FonteDividaAtiva {
+			tipoRegistro = RegistroDividaAtiva
+		}
+
+		detalhes := map[string]interface{}{
+			"referencia":    registro.Referencia,
+			"situacaoAtual": string(registro.Situacao),
+			"conector":      registro.Conector,
+			"atualizadoEm":  registro.AtualizadoEm,
+		}
+		for chave, valor := range registro.Detalhes {
+			detalhes[chave] = valor
+		}
+
+		restricoes = append(restricoes, RegistroCredito{
+			RegistroID:       registro.ID,
+			EntidadeID:       consulta.EntidadeID,
+			TipoEntidade:     registro.TipoEntidade,
+			DocumentoCliente: consulta.DocumentoCliente,
+			Valor:            registro.Valor,
+			DataOcorrencia:   registro.DataOcorrencia,
+			DataInclusao:     registro.AtualizadoEm,
+			TipoRegistro:     tipoRegistro,
+			OrigemRegistro:   OrigemRegistrosPublicos,
+			FonteID:          registro.FonteID,
+			FonteNome:        registro.FonteNome,
+			Detalhes:         detalhes,
+			MarketContext:    consulta.MarketContext,
+		})
+	}
+	return restricoes
+}
+
+// processarNotificacoes processa notificações obrigatórias por mercado
 func (bc *BureauCredito) processarNotificacoes(ctx context.Context, consulta ConsultaCredito, resultado *ResultadoConsulta) {
 	ctx, span := bc.observability.Tracer().Start(ctx, "processar_notificacoes")
 	defer span.End()
@@ -903,13 +1498,13 @@ func (bc *BureauCredito) processarNotificacoes(ctx context.Context, consulta Con
 			// Enviar notificação (simulado)
 			bc.logger.Info("Enviando notificação BNA para consulta completa",
 				zap.String("consulta_id", consulta.ConsultaID),
-				zap.String("documento_cliente", consulta.DocumentoCliente))
+				zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)))
 			
 			// Registrar evento de auditoria para notificação
 			bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 				"bna_notificacao_enviada",
 				fmt.Sprintf("Notificação BNA enviada para documento %s referente à consulta %s", 
-					consulta.DocumentoCliente, consulta.ConsultaID))
+					adapter.MaskPII(consulta.DocumentoCliente), consulta.ConsultaID))
 			
 			// Registrar métrica de notificação
 			bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_notificacoes", 
@@ -922,14 +1517,14 @@ func (bc *BureauCredito) processarNotificacoes(ctx context.Context, consulta Con
 			// Enviar notificação (simulado)
 			bc.logger.Info("Enviando notificação LGPD/BACEN para consulta com restrições",
 				zap.String("consulta_id", consulta.ConsultaID),
-				zap.String("documento_cliente", consulta.DocumentoCliente),
+				zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)),
 				zap.Int("qtd_restricoes", len(resultado.RestricoesList)))
 			
 			// Registrar evento de auditoria para notificação
 			bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 				"lgpd_bacen_notificacao_enviada",
 				fmt.Sprintf("Notificação LGPD/BACEN enviada para documento %s referente a %d restrições", 
-					consulta.DocumentoCliente, len(resultado.RestricoesList)))
+					adapter.MaskPII(consulta.DocumentoCliente), len(resultado.RestricoesList)))
 			
 			// Registrar métrica de notificação
 			bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_notificacoes", 
@@ -941,13 +1536,13 @@ func (bc *BureauCredito) processarNotificacoes(ctx context.Context, consulta Con
 		// Enviar notificação (simulado)
 		bc.logger.Info("Enviando notificação GDPR para consulta",
 			zap.String("consulta_id", consulta.ConsultaID),
-			zap.String("documento_cliente", consulta.DocumentoCliente))
+			zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)))
 		
 		// Registrar evento de auditoria para notificação
 		bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 			"gdpr_notificacao_enviada",
 			fmt.Sprintf("Notificação GDPR enviada para documento %s referente à consulta %s", 
-				consulta.DocumentoCliente, consulta.ConsultaID))
+				adapter.MaskPII(consulta.DocumentoCliente), consulta.ConsultaID))
 		
 		// Registrar métrica de notificação
 		bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_notificacoes", 
@@ -959,14 +1554,14 @@ func (bc *BureauCredito) processarNotificacoes(ctx context.Context, consulta Con
 			// Enviar notificação (simulado)
 			bc.logger.Info("Enviando notificação FCRA para consulta com risco elevado",
 				zap.String("consulta_id", consulta.ConsultaID),
-				zap.String("documento_cliente", consulta.DocumentoCliente),
+				zap.String("documento_cliente", adapter.MaskPII(consulta.DocumentoCliente)),
 				zap.String("faixa_risco", *resultado.FaixaRisco))
 			
 			// Registrar evento de auditoria para notificação
 			bc.observability.TraceAuditEvent(ctx, consulta.MarketContext, consulta.UsuarioID,
 				"fcra_notificacao_enviada",
 				fmt.Sprintf("Notificação FCRA enviada para documento %s referente à faixa de risco %s", 
-					consulta.DocumentoCliente, *resultado.FaixaRisco))
+					adapter.MaskPII(consulta.DocumentoCliente), *resultado.FaixaRisco))
 			
 			// Registrar métrica de notificação
 			bc.observability.RecordMetric(consulta.MarketContext, "bureau_credito_notificacoes", 
@@ -1008,20 +1603,46 @@ func (bc *BureauCredito) resetConsultasDiarias() {
 	bc.consultasDiarias = make(map[string]int)
 }
 
-// RegistrarRegraCompliance adiciona uma nova regra de compliance
+// RegistrarRegraCompliance adiciona uma nova regra de compliance; regras repetidas são ignoradas
 func (bc *BureauCredito) RegistrarRegraCompliance(regra RegrasCompliance) {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-
-	bc.regrasCompliance = append(bc.regrasCompliance, regra)
+	err := bc.regrasCompliance.Register(rules.Rule[*ConsultaCredito]{
+		ID:          regra.ID,
+		Market:      regra.Market,
+		Description: regra.Description,
+		Framework:   regra.Framework,
+		Types:       regra.MandatoryFor,
+		Evaluate:    rules.Validator(regra.Validate),
+	})
+	if err != nil {
+		bc.logger.Warn("Regra de compliance não registada",
+			zap.String("regra_id", regra.ID),
+			zap.Error(err))
+	}
 }
 
-// RegistrarRegraAcesso adiciona uma nova regra de acesso
+// RegistrarRegraAcesso adiciona uma nova regra de acesso; regras repetidas são ignoradas
 func (bc *BureauCredito) RegistrarRegraAcesso(regra RegraAcesso) {
-	bc.mutex.Lock()
-	defer bc.mutex.Unlock()
-
-	bc.regrasAcesso = append(bc.regrasAcesso, regra)
+	tiposEntidade := regra.TipoEntidade
+	err := bc.regrasAcesso.Register(rules.Rule[*ConsultaCredito]{
+		ID:          regra.ID,
+		Market:      regra.Market,
+		Description: regra.Description,
+		Types:       regra.TipoConsulta,
+		Applies: func(consulta *ConsultaCredito) bool {
+			for _, tipoEntidade := range tiposEntidade {
+				if tipoEntidade == consulta.TipoEntidade {
+					return true
+				}
+			}
+			return false
+		},
+		Evaluate: rules.Validator(regra.Validate),
+	})
+	if err != nil {
+		bc.logger.Warn("Regra de acesso não registada",
+			zap.String("regra_id", regra.ID),
+			zap.Error(err))
+	}
 }
 
 // Start inicia o serviço Bureau de Crédito
@@ -1030,6 +1651,22 @@ func (bc *BureauCredito) Start() error {
 	bc.wg.Add(1)
 	go bc.startDailyResetWorker()
 
+	if bc.relatorios != nil {
+		bc.relatorios.Start()
+	}
+	if bc.monitor != nil {
+		bc.monitor.Start()
+		bc.startMonitoringAPI()
+	}
+	if bc.agendador != nil {
+		bc.agendador.Start()
+		bc.startSchedulingAPI()
+	}
+	if bc.ingestaoRegistros != nil {
+		bc.ingestaoRegistros.Start()
+		bc.startRegistriesAPI()
+	}
+
 	// Registrar métrica de início do serviço
 	marketContext := adapter.MarketContext{
 		Market:     bc.config.Market,
@@ -1102,9 +1739,36 @@ func (bc *BureauCredito) Stop() {
 	
 	// Notificar workers para encerrar
 	close(bc.shutdown)
+	if bc.monitoringServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		bc.monitoringServer.Shutdown(shutdownCtx)
+		cancel()
+	}
+	if bc.monitor != nil {
+		bc.monitor.Stop()
+	}
+	if bc.schedulingServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		bc.schedulingServer.Shutdown(shutdownCtx)
+		cancel()
+	}
+	if bc.agendador != nil {
+		bc.agendador.Stop()
+	}
+	if bc.registriesServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		bc.registriesServer.Shutdown(shutdownCtx)
+		cancel()
+	}
+	if bc.ingestaoRegistros != nil {
+		bc.ingestaoRegistros.Stop()
+	}
 	
 	// Aguardar todos os workers encerrarem
 	bc.wg.Wait()
+	if bc.relatorios != nil {
+		bc.relatorios.Stop()
+	}
 	
 	// Registrar métrica de encerramento do serviço
 	marketContext := adapter.MarketContext{
@@ -1363,6 +2027,164 @@ func main() {
 	// Criar instância do Bureau de Crédito
 	bureau := NewBureauCredito(config, observability, logger)
 
+	// Configurar criptografia de PII por mercado (BUREAU_PII_KEY_<MERCADO>=<keyID>:<chave base64>)
+	keyRing, err := pii.LoadKeyRingFromEnv([]string{
+		constants.MarketAngola, constants.MarketBrazil, constants.MarketEU, constants.MarketUSA, constants.MarketGlobal,
+	})
+	if err != nil {
+		logger.Fatal("Falha ao carregar chaves de criptografia de PII", zap.Error(err))
+	}
+	if _, err := keyRing.ActiveKeyID(market); err == nil {
+		// Documento cifrado de forma determinística para permitir pesquisa
+		bureau.SetFieldEncryptor(pii.NewFieldEncryptor(keyRing, pii.FieldDocumentoCliente))
+	} else {
+		logger.Warn("Criptografia de PII desativada: nenhuma chave configurada para o mercado",
+			zap.String("market", market))
+	}
+
+	// Configurar armazenamento dos relatórios detalhados (S3/MinIO quando configurado)
+	var reportStorage reports.ObjectStorage = reports.NewInMemoryObjectStorage("bureau-relatorios")
+	if endpoint := os.Getenv("BUREAU_REPORTS_S3_ENDPOINT"); endpoint != "" {
+		s3Storage, err := reports.NewS3Storage(reports.S3Config{
+			Endpoint:        endpoint,
+			Region:          os.Getenv("BUREAU_REPORTS_S3_REGION"),
+			Bucket:          os.Getenv("BUREAU_REPORTS_S3_BUCKET"),
+			AccessKeyID:     os.Getenv("BUREAU_REPORTS_S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("BUREAU_REPORTS_S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("BUREAU_REPORTS_S3_PATH_STYLE") == "true", // MinIO
+		})
+		if err != nil {
+			logger.Fatal("Falha ao configurar armazenamento de relatórios", zap.Error(err))
+		}
+		reportStorage = s3Storage
+	} else {
+		logger.Warn("Relatórios mantidos em memória: BUREAU_REPORTS_S3_ENDPOINT não configurado")
+	}
+	bureau.SetReportGenerator(reports.NewReportGenerator(reportStorage, reports.NewInMemoryJobStore(),
+		reports.DefaultGeneratorConfig(), nil))
+
+	// Suprimir consultas duplicadas dentro da janela (BUREAU_DEDUP_WINDOW=0 desativa a reutilização)
+	dedupConfig := dedup.DefaultConfig()
+	if window := os.Getenv("BUREAU_DEDUP_WINDOW"); window != "" {
+		parsed, err := time.ParseDuration(window)
+		if err != nil {
+			logger.Fatal("Janela de supressão de consultas inválida", zap.String("window", window), zap.Error(err))
+		}
+		dedupConfig.Window = parsed
+	}
+	// Tenants excluídos da supressão (BUREAU_DEDUP_OPT_OUT_TENANTS=<tenant>,<tenant>)
+	for _, tenantID := range strings.Split(os.Getenv("BUREAU_DEDUP_OPT_OUT_TENANTS"), ",") {
+		if tenantID = strings.TrimSpace(tenantID); tenantID != "" {
+			dedupConfig.OptOutTenants = append(dedupConfig.OptOutTenants, tenantID)
+		}
+	}
+	bureau.SetConsultaSuppressor(dedup.NewSuppressor(dedupConfig))
+
+	// Sinais de fraude e heurísticas de identidade sintética (BUREAU_FRAUD_SIGNALS_ENABLED=true ativa o enriquecimento)
+	// Provedores de inteligência opcionais: BUREAU_FRAUD_<DEVICE|PHONE|EMAIL>_URL e BUREAU_FRAUD_<...>_API_KEY
+	if os.Getenv("BUREAU_FRAUD_SIGNALS_ENABLED") == "true" {
+		var provedores []fraudsignals.Provider
+		for _, tipo := range []fraudsignals.SignalType{fraudsignals.SignalDevice, fraudsignals.SignalPhone, fraudsignals.SignalEmail} {
+			prefixo := "BUREAU_FRAUD_" + strings.ToUpper(string(tipo))
+			if endpoint := os.Getenv(prefixo + "_URL"); endpoint != "" {
+				provedores = append(provedores, fraudsignals.NewHTTPProvider(nil, fraudsignals.HTTPProviderConfig{
+					Type:     tipo,
+					Endpoint: endpoint,
+					APIKey:   os.Getenv(prefixo + "_API_KEY"),
+				}))
+			}
+		}
+		fraudConfig := fraudsignals.DefaultConfig()
+		if timeout := os.Getenv("BUREAU_FRAUD_PROVIDER_TIMEOUT"); timeout != "" {
+			parsed, err := time.ParseDuration(timeout)
+			if err != nil {
+				logger.Fatal("Prazo dos provedores de sinais de fraude inválido", zap.String("timeout", timeout), zap.Error(err))
+			}
+			fraudConfig.ProviderTimeout = parsed
+		}
+		bureau.SetFraudSignalEnricher(fraudsignals.NewEnricher(fraudConfig, provedores...))
+	}
+
+	// Monitorização contínua de documentos (BUREAU_MONITORING_ADDR=:8091 ativa a API de subscrições)
+	if addr := os.Getenv("BUREAU_MONITORING_ADDR"); addr != "" {
+		monitoringConfig := monitoring.DefaultConfig()
+		monitoringConfig.ConsentimentoObrigatorio = make(map[string]bool, len(config.ConsentimentoObrigatorio))
+		for mercado, obrigatorio := range config.ConsentimentoObrigatorio {
+			monitoringConfig.ConsentimentoObrigatorio[strings.ToLower(mercado)] = obrigatorio
+		}
+		monitoringConfig.ConsentimentoPadrao = config.ConsentimentoObrigatorio[constants.MarketGlobal]
+		monitoringConfig.PermitirHTTP = environment == "development"
+		if interval := os.Getenv("BUREAU_MONITORING_POLL_INTERVAL"); interval != "" {
+			parsed, err := time.ParseDuration(interval)
+			if err != nil {
+				logger.Fatal("Intervalo de monitorização inválido", zap.String("interval", interval), zap.Error(err))
+			}
+			monitoringConfig.PollInterval = parsed
+		}
+
+		bureau.config.MonitoringAPIAddr = addr
+		bureau.SetMonitor(monitoring.NewMonitor(monitoring.NewInMemoryStore(), bureau, bureau,
+			monitoring.NewWebhookNotifier(nil, monitoring.DefaultWebhookConfig()), monitoringConfig))
+	}
+
+	// Consultas recorrentes de carteiras (BUREAU_SCHEDULING_ADDR=:8092 ativa a API dos jobs agendados)
+	if addr := os.Getenv("BUREAU_SCHEDULING_ADDR"); addr != "" {
+		schedulingConfig := scheduling.DefaultConfig()
+		schedulingConfig.ConsentimentoObrigatorio = make(map[string]bool, len(config.ConsentimentoObrigatorio))
+		for mercado, obrigatorio := range config.ConsentimentoObrigatorio {
+			schedulingConfig.ConsentimentoObrigatorio[strings.ToLower(mercado)] = obrigatorio
+		}
+		schedulingConfig.ConsentimentoPadrao = config.ConsentimentoObrigatorio[constants.MarketGlobal]
+		if interval := os.Getenv("BUREAU_SCHEDULING_POLL_INTERVAL"); interval != "" {
+			parsed, err := time.ParseDuration(interval)
+			if err != nil {
+				logger.Fatal("Intervalo de agendamento inválido", zap.String("interval", interval), zap.Error(err))
+			}
+			schedulingConfig.PollInterval = parsed
+		}
+
+		bureau.config.SchedulingAPIAddr = addr
+		bureau.SetScheduler(scheduling.NewScheduler(scheduling.NewInMemoryStore(scheduling.DefaultMaxHistory),
+			bureau, bureau, schedulingConfig))
+	}
+
+	// Ingestão de registos públicos (BUREAU_PROTESTO_API_URL e/ou BUREAU_DIVIDA_ATIVA_DIR ativam os conectores)
+	// BUREAU_REGISTRIES_ADDR=:8093 ativa a API de operação dos conectores e dos alertas de qualidade de dados
+	var conectoresRegistros []registries.Connector
+	if endpoint := os.Getenv("BUREAU_PROTESTO_API_URL"); endpoint != "" {
+		conectoresRegistros = append(conectoresRegistros, registries.NewProtestoAPIConnector(nil, registries.ProtestoAPIConfig{
+			Endpoint: endpoint,
+			APIKey:   os.Getenv("BUREAU_PROTESTO_API_KEY"),
+			Market:   constants.MarketBrazil,
+		}))
+	}
+	if dir := os.Getenv("BUREAU_DIVIDA_ATIVA_DIR"); dir != "" {
+		conectoresRegistros = append(conectoresRegistros, registries.NewDividaAtivaFileConnector(os.DirFS(dir), registries.DividaAtivaFileConfig{
+			Market: constants.MarketBrazil,
+		}))
+	}
+	if len(conectoresRegistros) > 0 {
+		intervaloRegistros := registries.DefaultSyncInterval
+		if interval := os.Getenv("BUREAU_REGISTRIES_SYNC_INTERVAL"); interval != "" {
+			parsed, err := time.ParseDuration(interval)
+			if err != nil {
+				logger.Fatal("Intervalo de sincronização dos registos públicos inválido", zap.String("interval", interval), zap.Error(err))
+			}
+			intervaloRegistros = parsed
+		}
+
+		indiceRegistros := registries.NewIndex()
+		ingestor := registries.NewIngestor(registries.NewInMemoryStore(registries.DefaultMaxHistory),
+			indiceRegistros, nil, registries.DefaultConfig())
+		for _, conector := range conectoresRegistros {
+			if err := ingestor.Register(conector, intervaloRegistros); err != nil {
+				logger.Fatal("Falha ao registar conector de registo público", zap.Error(err))
+			}
+		}
+		bureau.config.RegistriesAPIAddr = os.Getenv("BUREAU_REGISTRIES_ADDR")
+		bureau.SetPublicRegistries(ingestor, indiceRegistros)
+	}
+
 	// Configurar regras de compliance padrão
 	bureau.ConfigurarRegrasCompliancePadrao()
 
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Flags do comando metrics dashboards
+	dashboardMarkets    []string
+	dashboardOutputDir  string
+	dashboardDatasource string
+	dashboardRefresh    string
+	alertThresholds     = adapter.DefaultAlertThresholds()
+)
+
+// allMarkets são os mercados gerados quando nenhum é indicado
+var allMarkets = []string{
+	constants.MarketAngola,
+	constants.MarketBrazil,
+	constants.MarketEU,
+	constants.MarketUSA,
+	constants.MarketChina,
+	constants.MarketSADC,
+	constants.MarketGlobal,
+}
+
+// metricsDashboardsCmd gera dashboards Grafana e regras de alerta Prometheus por mercado
+var metricsDashboardsCmd = &cobra.Command{
+	Use:   "dashboards",
+	Short: "Gerar dashboards Grafana e regras de alerta Prometheus por mercado",
+	Long: `Gera, para cada mercado, um dashboard Grafana (JSON) e um arquivo de regras de alerta
+Prometheus (YAML) derivados das métricas efetivamente registradas pelo adaptador de
+observabilidade (hooks, elevações, validações, compliance e gateway de pagamentos).
+
+Os artefatos devem ser regenerados sempre que métricas forem adicionadas ou alteradas,
+mantendo dashboards e alertas sincronizados com o código.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		markets := dashboardMarkets
+		if len(markets) == 0 {
+			markets = allMarkets
+		}
+
+		if err := os.MkdirAll(dashboardOutputDir, 0755); err != nil {
+			return fmt.Errorf("falha ao criar diretório de saída: %w", err)
+		}
+
+		for _, market := range markets {
+			dashboard, err := adapter.GenerateGrafanaDashboard(adapter.DashboardOptions{
+				Market:     market,
+				Datasource: dashboardDatasource,
+				Refresh:    dashboardRefresh,
+			})
+			if err != nil {
+				return fmt.Errorf("falha ao gerar dashboard de %s: %w", market, err)
+			}
+			dashboardPath := filepath.Join(dashboardOutputDir, adapter.DashboardFileName(market))
+			if err := os.WriteFile(dashboardPath, dashboard, 0644); err != nil {
+				return fmt.Errorf("falha ao gravar dashboard de %s: %w", market, err)
+			}
+
+			rules, err := adapter.GeneratePrometheusRules(market, alertThresholds)
+			if err != nil {
+				return fmt.Errorf("falha ao gerar regras de alerta de %s: %w", market, err)
+			}
+			rulesPath := filepath.Join(dashboardOutputDir, adapter.RulesFileName(market))
+			if err := os.WriteFile(rulesPath, rules, 0644); err != nil {
+				return fmt.Errorf("falha ao gravar regras de alerta de %s: %w", market, err)
+			}
+
+			color.Green("✓ %s: %s, %s", market, dashboardPath, rulesPath)
+		}
+
+		fmt.Printf("%d métricas do catálogo incluídas em cada dashboard\n", len(adapter.MetricCatalog()))
+		return nil
+	},
+}
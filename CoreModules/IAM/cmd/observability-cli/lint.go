@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/cmd/observability-cli/lint"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/spf13/cobra"
+)
+
+// Formatos de saída do comando config lint
+const (
+	lintOutputJSON = "json"
+	lintOutputText = "text"
+)
+
+// Flag do comando config lint
+var lintOutput string
+
+// configLintCmd valida estaticamente um ficheiro de configuração de observabilidade
+var configLintCmd = &cobra.Command{
+	Use:   "lint <ficheiro>",
+	Short: "Validar estaticamente um ficheiro de configuração de observabilidade",
+	Long: `Valida um ficheiro de configuração de observabilidade e as combinações de mercado,
+tipo de tenant e tipo de hook nele declaradas contra o pacote de constantes e os metadados
+de compliance registados por mercado: mercados, tenants e hooks desconhecidos, níveis MFA
+ausentes ou abaixo do mínimo do mercado, retenção de logs abaixo do mínimo regulatório e
+aprovação dual desativada onde o mercado a exige.
+
+Os resultados são emitidos em JSON (padrão) ou texto; o comando termina com código 1
+quando existe algum erro, para uso em pipelines de CI.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if lintOutput != lintOutputJSON && lintOutput != lintOutputText {
+			return fmt.Errorf("formato de saída inválido: %s", lintOutput)
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("falha ao ler ficheiro de configuração: %w", err)
+		}
+
+		report := newConfigLinter().Lint(data)
+		report.File = args[0]
+
+		if lintOutput == lintOutputJSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("falha ao formatar resultados: %w", err)
+			}
+			fmt.Println(string(out))
+		} else {
+			printLintReport(report)
+		}
+
+		if !report.Valid {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// newConfigLinter cria o linter com os mercados suportados, a validação do adaptador
+// de observabilidade e os metadados de compliance registados por mercado
+func newConfigLinter() *lint.Linter {
+	return &lint.Linter{
+		Markets: allMarkets,
+		Validate: func(file lint.ConfigFile) error {
+			config := adapter.Config{
+				Environment:     file.Environment,
+				ServiceName:     file.ServiceName,
+				OTLPEndpoint:    file.OTLPEndpoint,
+				MetricsPort:     file.MetricsPort,
+				LogLevel:        file.LogLevel,
+				TraceSampleRate: file.TraceSampleRate,
+				MetricNamespace: file.MetricNamespace,
+			}
+			return config.Validate()
+		},
+		Requirements: func(market string) []lint.Requirement {
+			metadata := complianceMetadataFor(market)
+			requirements := make([]lint.Requirement, len(metadata))
+			for i, m := range metadata {
+				requirements[i] = lint.Requirement{
+					Market:               m.Market,
+					Framework:            m.Framework,
+					RequiresDualApproval: m.RequiresDualApproval,
+					MinimumMFALevel:      m.MinimumMFALevel,
+					LogRetentionYears:    m.LogRetentionYears,
+				}
+			}
+			return requirements
+		},
+	}
+}
+
+// printLintReport exibe os resultados do linter em texto
+func printLintReport(report *lint.Report) {
+	for _, finding := range report.Findings {
+		location := report.File
+		if finding.Path != "" {
+			location += ": " + finding.Path
+		}
+		if finding.Severity == lint.SeverityError {
+			color.Red("✗ %s [%s] %s", location, finding.Code, finding.Message)
+		} else {
+			color.Yellow("⚠ %s [%s] %s", location, finding.Code, finding.Message)
+		}
+	}
+
+	if report.Valid {
+		color.Green("✓ %s: %d erros, %d avisos", report.File, report.Errors, report.Warnings)
+	} else {
+		color.Red("%s: %d erros, %d avisos", report.File, report.Errors, report.Warnings)
+	}
+}
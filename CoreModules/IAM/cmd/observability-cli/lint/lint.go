@@ -0,0 +1,249 @@
+// Package lint valida estaticamente os ficheiros de configuração de observabilidade e as
+// combinações de mercado, tipo de tenant e tipo de hook contra os requisitos de compliance do mercado.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/innovabiz/iam/constants"
+	"gopkg.in/yaml.v3"
+)
+
+// Severidades dos resultados do linter
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Códigos dos resultados do linter
+const (
+	codeInvalidFormat     = "invalid_format"
+	codeInvalidConfig     = "invalid_config"
+	codeNoHooks           = "no_hooks"
+	codeUnknownMarket     = "unknown_market"
+	codeUnknownTenantType = "unknown_tenant_type"
+	codeUnknownHookType   = "unknown_hook_type"
+	codeMissingMFALevel   = "missing_mfa_level"
+	codeUnknownMFALevel   = "unknown_mfa_level"
+	codeMFABelowMinimum   = "mfa_below_minimum"
+	codeMissingRetention  = "missing_retention"
+	codeRetentionTooShort = "retention_below_minimum"
+	codeDualApproval      = "dual_approval_disabled"
+	codeDuplicateHook     = "duplicate_hook"
+)
+
+// Valores reconhecidos pelo pacote de constantes
+var (
+	knownTenantTypes = map[string]bool{
+		constants.TenantFinancial: true, constants.TenantRetail: true, constants.TenantGovernment: true,
+		constants.TenantHealthcare: true, constants.TenantTelecom: true, constants.TenantEnergy: true,
+		constants.TenantEducation: true, constants.TenantManufacturing: true,
+	}
+	knownHookTypes = map[string]bool{
+		constants.HookTypePrivilegeElevation: true, constants.HookTypeMFAValidation: true,
+		constants.HookTypeScopeValidation: true, constants.HookTypeTokenIntrospection: true,
+		constants.HookTypeUserAuthentication: true, constants.HookTypeAuditLogging: true,
+		constants.HookTypeRateLimiting: true, constants.HookTypeSecurityAlert: true,
+	}
+	// Níveis MFA em ordem crescente de segurança
+	mfaLevelOrder = []string{
+		constants.MFALevelNone, constants.MFALevelBasic, constants.MFALevelMedium,
+		constants.MFALevelHigh, constants.MFALevelAdvanced,
+	}
+)
+
+// ConfigFile representa o ficheiro de configuração de observabilidade validado pelo linter
+type ConfigFile struct {
+	Environment     string       `yaml:"environment"`
+	ServiceName     string       `yaml:"service_name"`
+	OTLPEndpoint    string       `yaml:"otlp_endpoint"`
+	MetricsPort     int          `yaml:"metrics_port"`
+	LogLevel        string       `yaml:"log_level"`
+	TraceSampleRate float64      `yaml:"trace_sample_rate"`
+	MetricNamespace string       `yaml:"metric_namespace"`
+	Hooks           []HookConfig `yaml:"hooks"`
+}
+
+// HookConfig representa a configuração de um hook para uma combinação de mercado e tenant
+type HookConfig struct {
+	Market            string `yaml:"market"`
+	TenantType        string `yaml:"tenant_type"`
+	HookType          string `yaml:"hook_type"`
+	MFALevel          string `yaml:"mfa_level"`
+	LogRetentionYears int    `yaml:"log_retention_years"`
+	DualApproval      *bool  `yaml:"dual_approval"`
+}
+
+// Finding representa um problema encontrado na configuração
+type Finding struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	// Localização do problema no ficheiro (ex: hooks[2].mfa_level)
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Report resume os resultados do linter para um ficheiro
+type Report struct {
+	File     string    `json:"file"`
+	Valid    bool      `json:"valid"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Findings []Finding `json:"findings"`
+}
+
+// Requirement é um requisito de compliance de um framework aplicável a um mercado
+type Requirement struct {
+	Market               string
+	Framework            string
+	RequiresDualApproval bool
+	MinimumMFALevel      string
+	LogRetentionYears    int
+}
+
+// Linter valida as configurações contra os mercados suportados e os seus requisitos de compliance
+type Linter struct {
+	// Markets são os mercados suportados pela plataforma
+	Markets []string
+
+	// Validate valida os parâmetros globais de observabilidade do ficheiro
+	Validate func(file ConfigFile) error
+
+	// Requirements retorna os requisitos de compliance aplicáveis ao mercado;
+	// um mercado desconhecido deve ser validado contra os requisitos do mercado global
+	Requirements func(market string) []Requirement
+}
+
+// Lint valida o conteúdo de um ficheiro de configuração de observabilidade
+func (l *Linter) Lint(data []byte) *Report {
+	report := &Report{Findings: []Finding{}}
+
+	var file ConfigFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		report.add(SeverityError, codeInvalidFormat, "", fmt.Sprintf("formato inválido: %v", err))
+		return report.finish()
+	}
+
+	if err := l.Validate(file); err != nil {
+		report.add(SeverityError, codeInvalidConfig, "", err.Error())
+	}
+
+	if len(file.Hooks) == 0 {
+		report.add(SeverityWarning, codeNoHooks, "hooks", "nenhuma combinação de mercado, tenant e hook declarada")
+	}
+
+	seen := make(map[string]int)
+	for i, hook := range file.Hooks {
+		path := fmt.Sprintf("hooks[%d]", i)
+		key := hook.Market + "/" + hook.TenantType + "/" + hook.HookType
+		if first, ok := seen[key]; ok {
+			report.add(SeverityWarning, codeDuplicateHook, path,
+				fmt.Sprintf("combinação %s já declarada em hooks[%d]", key, first))
+		} else {
+			seen[key] = i
+		}
+		l.lintHook(report, path, hook)
+	}
+
+	return report.finish()
+}
+
+// lintHook valida uma combinação de mercado, tenant e hook contra os requisitos de compliance do mercado
+func (l *Linter) lintHook(report *Report, path string, hook HookConfig) {
+	if !l.isKnownMarket(hook.Market) {
+		report.add(SeverityError, codeUnknownMarket, path+".market",
+			fmt.Sprintf("mercado desconhecido: %q", hook.Market))
+	}
+	if !knownTenantTypes[hook.TenantType] {
+		report.add(SeverityError, codeUnknownTenantType, path+".tenant_type",
+			fmt.Sprintf("tipo de tenant desconhecido: %q", hook.TenantType))
+	}
+	if !knownHookTypes[hook.HookType] {
+		report.add(SeverityError, codeUnknownHookType, path+".hook_type",
+			fmt.Sprintf("tipo de hook desconhecido: %q", hook.HookType))
+	}
+
+	metadata := l.Requirements(hook.Market)
+
+	level := mfaLevelRank(hook.MFALevel)
+	switch {
+	case hook.MFALevel == "":
+		report.add(SeverityError, codeMissingMFALevel, path+".mfa_level", "nível MFA não configurado")
+	case level < 0:
+		report.add(SeverityError, codeUnknownMFALevel, path+".mfa_level",
+			fmt.Sprintf("nível MFA desconhecido: %q", hook.MFALevel))
+	default:
+		for _, m := range metadata {
+			if level < mfaLevelRank(m.MinimumMFALevel) {
+				report.add(SeverityError, codeMFABelowMinimum, path+".mfa_level",
+					fmt.Sprintf("nível MFA %s abaixo do mínimo %s exigido por %s no mercado %s",
+						hook.MFALevel, m.MinimumMFALevel, m.Framework, m.Market))
+			}
+		}
+	}
+
+	if hook.LogRetentionYears <= 0 {
+		report.add(SeverityError, codeMissingRetention, path+".log_retention_years", "retenção de logs não configurada")
+	} else {
+		for _, m := range metadata {
+			if hook.LogRetentionYears < m.LogRetentionYears {
+				report.add(SeverityError, codeRetentionTooShort, path+".log_retention_years",
+					fmt.Sprintf("retenção de %d anos abaixo do mínimo de %d anos exigido por %s no mercado %s",
+						hook.LogRetentionYears, m.LogRetentionYears, m.Framework, m.Market))
+			}
+		}
+	}
+
+	// A aprovação dual aplica-se às elevações de privilégio
+	if hook.HookType == constants.HookTypePrivilegeElevation && hook.DualApproval != nil && !*hook.DualApproval {
+		for _, m := range metadata {
+			if m.RequiresDualApproval {
+				report.add(SeverityError, codeDualApproval, path+".dual_approval",
+					fmt.Sprintf("aprovação dual exigida por %s no mercado %s", m.Framework, m.Market))
+				break
+			}
+		}
+	}
+}
+
+// isKnownMarket indica se o mercado é um dos mercados suportados pela plataforma
+func (l *Linter) isKnownMarket(market string) bool {
+	for _, known := range l.Markets {
+		if market == known {
+			return true
+		}
+	}
+	return false
+}
+
+// mfaLevelRank retorna a posição do nível MFA na ordem crescente de segurança, ou -1 se desconhecido
+func mfaLevelRank(level string) int {
+	for i, known := range mfaLevelOrder {
+		if strings.EqualFold(level, known) {
+			return i
+		}
+	}
+	return -1
+}
+
+// add acrescenta um resultado ao relatório
+func (r *Report) add(severity, code, path, message string) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Code: code, Path: path, Message: message})
+}
+
+// finish contabiliza os erros e avisos do relatório
+func (r *Report) finish() *Report {
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	r.Valid = r.Errors == 0
+	return r
+}
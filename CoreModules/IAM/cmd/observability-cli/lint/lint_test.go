@@ -0,0 +1,199 @@
+package lint
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requisitosTeste reproduz os metadados de compliance de Angola, Brasil e do mercado global
+var requisitosTeste = map[string][]Requirement{
+	constants.MarketAngola: {
+		{Framework: constants.FrameworkBNA, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 7},
+	},
+	constants.MarketBrazil: {
+		{Framework: constants.FrameworkLGPD, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 5},
+		{Framework: constants.FrameworkBACEN, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 10},
+	},
+	constants.MarketGlobal: {
+		{Framework: constants.FrameworkISO27001, MinimumMFALevel: constants.MFALevelMedium, LogRetentionYears: 3},
+	},
+}
+
+// linterTeste cria um linter com os requisitos de teste e uma validação que exige o nome do serviço
+func linterTeste() *Linter {
+	return &Linter{
+		Markets: []string{constants.MarketAngola, constants.MarketBrazil, constants.MarketGlobal},
+		Validate: func(file ConfigFile) error {
+			if file.ServiceName == "" {
+				return errors.New("nome do serviço é obrigatório")
+			}
+			return nil
+		},
+		Requirements: func(market string) []Requirement {
+			registered, ok := requisitosTeste[market]
+			if !ok {
+				market = constants.MarketGlobal
+				registered = requisitosTeste[market]
+			}
+			requirements := make([]Requirement, len(registered))
+			for i, r := range registered {
+				r.Market = market
+				requirements[i] = r
+			}
+			return requirements
+		},
+	}
+}
+
+// codigos retorna os códigos e localizações dos resultados, na ordem em que foram emitidos
+func codigos(report *Report) [][2]string {
+	result := make([][2]string, 0, len(report.Findings))
+	for _, finding := range report.Findings {
+		result = append(result, [2]string{finding.Code, finding.Path})
+	}
+	return result
+}
+
+func TestLintValidConfig(t *testing.T) {
+	report := linterTeste().Lint([]byte(`
+environment: production
+service_name: mcp-iam-hooks
+hooks:
+  - market: Angola
+    tenant_type: Financial
+    hook_type: PrivilegeElevation
+    mfa_level: HIGH
+    log_retention_years: 7
+    dual_approval: true
+  - market: Global
+    tenant_type: Retail
+    hook_type: AuditLogging
+    mfa_level: medium
+    log_retention_years: 3
+`))
+
+	assert.True(t, report.Valid)
+	assert.Zero(t, report.Errors)
+	assert.Zero(t, report.Warnings)
+	assert.NotNil(t, report.Findings, "sem resultados a lista é vazia e não nula, para o JSON")
+	assert.Empty(t, report.Findings)
+}
+
+func TestLintInvalidFormat(t *testing.T) {
+	tests := []struct {
+		nome string
+		data string
+	}{
+		{"YAML inválido", "hooks: [market: Angola"},
+		{"campo desconhecido", "service_name: x\nretention: 7\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			report := linterTeste().Lint([]byte(tt.data))
+			require.Len(t, report.Findings, 1)
+			assert.Equal(t, codeInvalidFormat, report.Findings[0].Code)
+			assert.Contains(t, report.Findings[0].Message, "formato inválido")
+			assert.False(t, report.Valid)
+			assert.Equal(t, 1, report.Errors)
+		})
+	}
+}
+
+func TestLintGlobalConfig(t *testing.T) {
+	report := linterTeste().Lint([]byte("environment: production\n"))
+
+	assert.Equal(t, [][2]string{{codeInvalidConfig, ""}, {codeNoHooks, "hooks"}}, codigos(report))
+	assert.Equal(t, "nome do serviço é obrigatório", report.Findings[0].Message)
+	assert.Equal(t, SeverityWarning, report.Findings[1].Severity)
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+	assert.False(t, report.Valid)
+}
+
+func TestLintHooks(t *testing.T) {
+	tests := []struct {
+		nome     string
+		hook     string
+		codigos  [][2]string
+		mensagem string
+	}{
+		{
+			"valores desconhecidos validados contra o mercado global",
+			"{market: Mars, tenant_type: Casino, hook_type: Teleport, mfa_level: ultra, log_retention_years: 3}",
+			[][2]string{{codeUnknownMarket, "hooks[0].market"}, {codeUnknownTenantType, "hooks[0].tenant_type"}, {codeUnknownHookType, "hooks[0].hook_type"}, {codeUnknownMFALevel, "hooks[0].mfa_level"}},
+			`mercado desconhecido: "Mars"`,
+		},
+		{
+			"nível MFA e retenção em falta",
+			"{market: Angola, tenant_type: Financial, hook_type: MFAValidation}",
+			[][2]string{{codeMissingMFALevel, "hooks[0].mfa_level"}, {codeMissingRetention, "hooks[0].log_retention_years"}},
+			"nível MFA não configurado",
+		},
+		{
+			"nível MFA abaixo do mínimo do mercado",
+			"{market: Angola, tenant_type: Financial, hook_type: MFAValidation, mfa_level: medium, log_retention_years: 7}",
+			[][2]string{{codeMFABelowMinimum, "hooks[0].mfa_level"}},
+			"nível MFA medium abaixo do mínimo high exigido por BNA no mercado Angola",
+		},
+		{
+			"retenção abaixo do mínimo de cada framework",
+			"{market: Brazil, tenant_type: Financial, hook_type: AuditLogging, mfa_level: advanced, log_retention_years: 4}",
+			[][2]string{{codeRetentionTooShort, "hooks[0].log_retention_years"}, {codeRetentionTooShort, "hooks[0].log_retention_years"}},
+			"retenção de 4 anos abaixo do mínimo de 5 anos exigido por LGPD no mercado Brazil",
+		},
+		{
+			"aprovação dual desativada onde o mercado a exige",
+			"{market: Brazil, tenant_type: Financial, hook_type: PrivilegeElevation, mfa_level: high, log_retention_years: 10, dual_approval: false}",
+			[][2]string{{codeDualApproval, "hooks[0].dual_approval"}},
+			"aprovação dual exigida por LGPD no mercado Brazil",
+		},
+		{
+			"aprovação dual desativada sem exigência do mercado",
+			"{market: Global, tenant_type: Retail, hook_type: PrivilegeElevation, mfa_level: medium, log_retention_years: 3, dual_approval: false}",
+			[][2]string{},
+			"",
+		},
+		{
+			"aprovação dual aplica-se apenas às elevações de privilégio",
+			"{market: Angola, tenant_type: Financial, hook_type: AuditLogging, mfa_level: high, log_retention_years: 7, dual_approval: false}",
+			[][2]string{},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			report := linterTeste().Lint([]byte("service_name: mcp-iam-hooks\nhooks:\n  - " + tt.hook + "\n"))
+
+			assert.Equal(t, tt.codigos, codigos(report))
+			if tt.mensagem != "" {
+				assert.Equal(t, tt.mensagem, report.Findings[0].Message)
+			}
+			assert.Equal(t, len(tt.codigos) == 0, report.Valid)
+			assert.Equal(t, len(tt.codigos), report.Errors)
+		})
+	}
+}
+
+func TestLintDuplicateHook(t *testing.T) {
+	hook := "{market: Global, tenant_type: Retail, hook_type: AuditLogging, mfa_level: medium, log_retention_years: 3}"
+	report := linterTeste().Lint([]byte("service_name: x\nhooks:\n  - " + hook + "\n  - " + hook + "\n"))
+
+	assert.Equal(t, [][2]string{{codeDuplicateHook, "hooks[1]"}}, codigos(report))
+	assert.Equal(t, "combinação Global/Retail/AuditLogging já declarada em hooks[0]", report.Findings[0].Message)
+	assert.True(t, report.Valid, "duplicados são avisos e não invalidam a configuração")
+	assert.Equal(t, 1, report.Warnings)
+}
+
+func TestMFALevelRank(t *testing.T) {
+	assert.Equal(t, 0, mfaLevelRank(constants.MFALevelNone))
+	assert.Equal(t, 3, mfaLevelRank("High"))
+	assert.Less(t, mfaLevelRank(constants.MFALevelBasic), mfaLevelRank(constants.MFALevelAdvanced))
+	assert.Equal(t, -1, mfaLevelRank("ultra"))
+	assert.Equal(t, -1, mfaLevelRank(""))
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Sufixo dos ficheiros de eventos de compliance (<data>-<categoria>-events.log)
+	complianceLogSuffix = "-events.log"
+)
+
+var (
+	// Diretório de chaves dos tenants para cifra dos logs de compliance
+	cfgComplianceKeysPath string
+
+	// Flags do comando logs query
+	queryTenant   string
+	queryCategory string
+	querySince    string
+	queryUntil    string
+	queryContains string
+
+	// Flag do comando logs rotate-key
+	rotateTenant string
+
+	// Flag do comando logs replay-quarantine
+	quarantinePath string
+)
+
+// logsCmd representa o comando para gerenciar logs de compliance
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Consultar e gerenciar logs de compliance",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// logsQueryCmd consulta os logs de compliance, decifrando os eventos dos tenants autorizados
+var logsQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Consultar logs de compliance",
+	Long: fmt.Sprintf(`Consulta os logs de compliance do diretório configurado.
+
+Eventos cifrados são decifrados de forma transparente com as chaves do tenant,
+desde que o operador conste da lista de operadores autorizados do tenant.
+O operador é o sujeito do token de acesso do IAM em $%s, validado no
+endpoint de introspeção $%s.
+O filtro de mercado é aplicado apenas quando --market é informado.`, envOperatorToken, envIntrospectionURL),
+	Run: func(cmd *cobra.Command, args []string) {
+		since, until, err := parseQueryDates(querySince, queryUntil)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		// Decifrar exige um operador autenticado no IAM; sem chaves apenas os eventos em claro são lidos
+		var operator string
+		var provider *adapter.FileKeyProvider
+		var logCipher *adapter.ComplianceLogCipher
+		if cfgComplianceKeysPath != "" {
+			operator, err = authenticateOperator(context.Background())
+			if err != nil {
+				color.Red("Erro ao autenticar operador: %v", err)
+				os.Exit(1)
+			}
+			provider = adapter.NewFileKeyProvider(cfgComplianceKeysPath)
+			logCipher = adapter.NewComplianceLogCipher(provider)
+		}
+
+		market := ""
+		if cmd.Flags().Changed("market") {
+			market = cfgMarket
+		}
+
+		files, err := findComplianceLogs(cfgComplianceLogsPath, market, queryCategory, since, until)
+		if err != nil {
+			color.Red("Erro ao listar logs de compliance: %v", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		authorized := make(map[string]bool)
+		var matched, unauthorized, failed int
+
+		for _, file := range files {
+			err := scanComplianceLog(file, func(line string) {
+				record, encrypted := adapter.ParseComplianceRecord(line)
+				if !encrypted {
+					// Eventos em texto claro não têm tenant e só aparecem sem filtro de tenant
+					if queryTenant == "" && strings.Contains(line, queryContains) {
+						fmt.Println(line)
+						matched++
+					}
+					return
+				}
+
+				if queryTenant != "" && record.Tenant != queryTenant {
+					return
+				}
+				if provider == nil {
+					failed++
+					return
+				}
+
+				allowed, checked := authorized[record.Tenant]
+				if !checked {
+					allowed = provider.AuthorizeOperator(ctx, record.Tenant, operator) == nil
+					authorized[record.Tenant] = allowed
+				}
+				if !allowed {
+					unauthorized++
+					return
+				}
+
+				plaintext, err := logCipher.Decrypt(ctx, record)
+				if err != nil {
+					failed++
+					return
+				}
+				if strings.Contains(string(plaintext), queryContains) {
+					fmt.Printf("[%s] %s\n", record.Tenant, plaintext)
+					matched++
+				}
+			})
+			if err != nil {
+				color.Yellow("⚠ Falha ao ler %s: %v", file, err)
+			}
+		}
+
+		color.Cyan("%d evento(s) encontrado(s) em %d ficheiro(s)", matched, len(files))
+		if unauthorized > 0 {
+			color.Yellow("⚠ %d evento(s) omitido(s): operador '%s' não autorizado para o tenant", unauthorized, operator)
+		}
+		if failed > 0 {
+			if provider == nil {
+				color.Yellow("⚠ %d evento(s) cifrado(s) omitido(s): informe --keys-path para decifrar", failed)
+			} else {
+				color.Yellow("⚠ %d evento(s) cifrado(s) não puderam ser decifrados", failed)
+			}
+		}
+	},
+}
+
+// logsRotateKeyCmd gera uma nova versão da chave de um tenant
+var logsRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Rodar a chave de cifra dos logs de compliance de um tenant",
+	Long: `Gera uma nova versão da chave do tenant e a torna ativa para eventos novos.
+
+As versões anteriores são mantidas no diretório de chaves, permitindo decifrar
+o histórico sem recifrá-lo.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfgComplianceKeysPath == "" {
+			color.Red("Diretório de chaves não configurado (--keys-path)")
+			os.Exit(1)
+		}
+
+		provider := adapter.NewFileKeyProvider(cfgComplianceKeysPath)
+		key, err := provider.Rotate(context.Background(), rotateTenant)
+		if err != nil {
+			color.Red("Erro ao rodar chave do tenant %s: %v", rotateTenant, err)
+			os.Exit(1)
+		}
+
+		color.Green("✓ Chave do tenant %s rodada para a versão %s", rotateTenant, key.Version)
+		color.Cyan("Emissores em execução passam a usar a nova versão em até 1 minuto")
+	},
+}
+
+// logsReplayQuarantineCmd cifra e grava nos logs os eventos que ficaram em quarentena
+var logsReplayQuarantineCmd = &cobra.Command{
+	Use:   "replay-quarantine",
+	Short: "Reprocessar eventos de compliance em quarentena",
+	Long: `Cifra com a chave ativa do tenant os eventos que o serviço não conseguiu cifrar
+e grava-os nos logs de compliance, no ficheiro do dia em que ocorreram.
+
+Os eventos cuja chave continua indisponível permanecem em quarentena.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfgComplianceKeysPath == "" {
+			color.Red("Diretório de chaves não configurado (--keys-path)")
+			os.Exit(1)
+		}
+
+		dir := quarantinePath
+		if dir == "" {
+			dir = adapter.DefaultComplianceQuarantinePath(cfgComplianceLogsPath)
+		}
+		quarantine := adapter.NewFileComplianceQuarantine(dir)
+		logCipher := adapter.NewComplianceLogCipher(adapter.NewFileKeyProvider(cfgComplianceKeysPath))
+
+		replayed, err := quarantine.Replay(context.Background(), logCipher, cfgComplianceLogsPath)
+		color.Cyan("%d evento(s) reprocessado(s) a partir de %s", replayed, dir)
+		if err != nil {
+			color.Red("Eventos mantidos em quarentena: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ Quarentena vazia")
+	},
+}
+
+// parseQueryDates interpreta o intervalo de datas da consulta (AAAA-MM-DD)
+func parseQueryDates(sinceStr, untilStr string) (time.Time, time.Time, error) {
+	var since, until time.Time
+	var err error
+
+	if sinceStr != "" {
+		if since, err = time.Parse("2006-01-02", sinceStr); err != nil {
+			return since, until, fmt.Errorf("data inicial inválida: %s", sinceStr)
+		}
+	}
+	if untilStr != "" {
+		if until, err = time.Parse("2006-01-02", untilStr); err != nil {
+			return since, until, fmt.Errorf("data final inválida: %s", untilStr)
+		}
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return since, until, errors.New("a data final é anterior à data inicial")
+	}
+
+	return since, until, nil
+}
+
+// findComplianceLogs lista os ficheiros de eventos que satisfazem os filtros, ordenados por caminho
+func findComplianceLogs(root, market, category string, since, until time.Time) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), complianceLogSuffix) {
+			return nil
+		}
+
+		// O primeiro nível abaixo da raiz corresponde ao mercado
+		if market != "" {
+			rel, err := filepath.Rel(root, path)
+			if err != nil || strings.Split(rel, string(filepath.Separator))[0] != market {
+				return nil
+			}
+		}
+
+		name := strings.TrimSuffix(info.Name(), complianceLogSuffix)
+		if len(name) < len("2006-01-02-") {
+			return nil
+		}
+		date, err := time.Parse("2006-01-02", name[:10])
+		if err != nil {
+			return nil
+		}
+		if category != "" && name[11:] != category {
+			return nil
+		}
+		if (!since.IsZero() && date.Before(since)) || (!until.IsZero() && date.After(until)) {
+			return nil
+		}
+
+		files = append(files, path)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	sort.Strings(files)
+	return files, err
+}
+
+// scanComplianceLog percorre as linhas de um ficheiro de eventos de compliance
+func scanComplianceLog(path string, fn func(line string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			fn(line)
+		}
+	}
+	return scanner.Err()
+}
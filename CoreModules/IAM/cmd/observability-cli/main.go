@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/innovabiz/iam/cmd/observability-cli/profiles"
+	"github.com/innovabiz/iam/cmd/observability-cli/tracing"
 	"github.com/innovabiz/iam/constants"
 	"github.com/innovabiz/iam/observability/adapter"
 	"github.com/spf13/cobra"
@@ -54,6 +56,9 @@ a observabilidade de hooks MCP-IAM da plataforma INNOVABIZ.
 Suporta configurações específicas por mercado, tenant e tipo de hook,
 com integração a métricas Prometheus, tracing OpenTelemetry e logging 
 estruturado via Zap.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyProfileAndEnv(cmd)
+	},
 }
 
 // configCmd representa o comando para gerenciar configurações
@@ -281,6 +286,19 @@ var metricsExposeCmd = &cobra.Command{
 			os.Exit(1)
 		}
 		
+		// Com token administrativo, o endpoint de configuração em tempo de execução fica ativo;
+		// as alterações são auditadas com o operador autenticado no IAM
+		if token := os.Getenv(envAdminToken); token != "" {
+			operator, err := authenticateOperator(context.Background())
+			if err != nil {
+				color.Red("Endpoint administrativo não ativado: %v", err)
+				os.Exit(1)
+			}
+			config.WithRuntimeAdmin(adapter.RuntimeAdminConfig{
+				Tokens: map[string]string{token: operator},
+			})
+		}
+		
 		color.Cyan("Inicializando adaptador de observabilidade...")
 		obs, err := adapter.NewHookObservability(config)
 		if err != nil {
@@ -295,6 +313,9 @@ var metricsExposeCmd = &cobra.Command{
 		
 		color.Green("✓ Servidor de métricas iniciado na porta %d", config.MetricsPort)
 		color.Cyan("Acesse http://localhost:%d/metrics no navegador", config.MetricsPort)
+		if config.RuntimeAdmin != nil {
+			color.Cyan("Endpoint administrativo em http://localhost:%d%s", config.MetricsPort, adapter.DefaultRuntimeAdminPath)
+		}
 		color.Cyan("Pressione Ctrl+C para encerrar")
 		
 		// Aguardar indefinidamente (servidor HTTP roda em goroutine separada)
@@ -316,6 +337,11 @@ func buildConfig() adapter.Config {
 		StructuredLogging:     cfgStructuredLogging,
 		LogLevel:              cfgLogLevel,
 	}
+
+	// Cifrar logs de compliance por tenant quando há diretório de chaves configurado
+	if cfgComplianceKeysPath != "" {
+		config.WithComplianceEncryption(cfgComplianceKeysPath, nil)
+	}
 	return config
 }
 
@@ -448,56 +474,47 @@ func testSecurityEvent(ctx context.Context, obs *adapter.HookObservability, mark
 	color.Green("✓ Evento de segurança registrado")
 }
 
+// marketComplianceMetadata são os metadados de compliance registados por mercado;
+// os mercados sem entrada própria usam os do mercado global
+var marketComplianceMetadata = map[string][]adapter.ComplianceMetadata{
+	constants.MarketAngola: {
+		{Framework: constants.FrameworkBNA, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 7},
+	},
+	constants.MarketBrazil: {
+		{Framework: constants.FrameworkLGPD, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 5},
+		{Framework: constants.FrameworkBACEN, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 10},
+	},
+	constants.MarketEU: {
+		{Framework: constants.FrameworkGDPR, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelHigh, LogRetentionYears: 7},
+	},
+	constants.MarketUSA: {
+		{Framework: constants.FrameworkSOX, RequiresDualApproval: true, MinimumMFALevel: constants.MFALevelMedium, LogRetentionYears: 7},
+	},
+	constants.MarketGlobal: {
+		{Framework: constants.FrameworkISO27001, RequiresDualApproval: false, MinimumMFALevel: constants.MFALevelMedium, LogRetentionYears: 3},
+	},
+}
+
+// complianceMetadataFor retorna os metadados de compliance aplicáveis ao mercado
+func complianceMetadataFor(market string) []adapter.ComplianceMetadata {
+	registered, ok := marketComplianceMetadata[market]
+	if !ok {
+		market = constants.MarketGlobal
+		registered = marketComplianceMetadata[market]
+	}
+
+	metadata := make([]adapter.ComplianceMetadata, len(registered))
+	for i, m := range registered {
+		m.Market = market
+		metadata[i] = m
+	}
+	return metadata
+}
+
 // registerMarketComplianceMetadata registra metadados de compliance específicos do mercado
 func registerMarketComplianceMetadata(obs *adapter.HookObservability, market string) {
-	switch market {
-	case constants.MarketAngola:
-		obs.RegisterComplianceMetadata(
-			constants.MarketAngola,
-			"BNA",
-			true, // Requer aprovação dual
-			constants.MFALevelHigh,
-			7, // 7 anos de retenção
-		)
-	case constants.MarketBrazil:
-		obs.RegisterComplianceMetadata(
-			constants.MarketBrazil,
-			"LGPD",
-			true, // Requer aprovação dual
-			constants.MFALevelHigh,
-			5, // 5 anos de retenção
-		)
-		obs.RegisterComplianceMetadata(
-			constants.MarketBrazil,
-			"BACEN",
-			true, // Requer aprovação dual
-			constants.MFALevelHigh,
-			10, // 10 anos de retenção
-		)
-	case constants.MarketEU:
-		obs.RegisterComplianceMetadata(
-			constants.MarketEU,
-			"GDPR",
-			true, // Requer aprovação dual
-			constants.MFALevelHigh,
-			7, // 7 anos de retenção
-		)
-	case constants.MarketUSA:
-		obs.RegisterComplianceMetadata(
-			constants.MarketUSA,
-			"SOX",
-			true, // Requer aprovação dual
-			constants.MFALevelMedium,
-			7, // 7 anos de retenção
-		)
-	default:
-		obs.RegisterComplianceMetadata(
-			constants.MarketGlobal,
-			"ISO27001",
-			false, // Não requer aprovação dual por padrão
-			constants.MFALevelMedium,
-			3, // 3 anos de retenção
-		)
+	for _, m := range complianceMetadataFor(market) {
+		obs.RegisterComplianceMetadata(m.Market, m.Framework, m.RequiresDualApproval, m.MinimumMFALevel, m.LogRetentionYears)
 	}
 }
 
@@ -517,21 +534,75 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgOTLPEndpoint, "otlp-endpoint", "", "Endpoint para exportação OpenTelemetry (ex: localhost:4317)")
 	rootCmd.PersistentFlags().IntVar(&cfgMetricsPort, "metrics-port", 9090, "Porta para métricas Prometheus")
 	rootCmd.PersistentFlags().StringVar(&cfgComplianceLogsPath, "logs-path", defaultLogsPath, "Caminho para logs de compliance")
+	rootCmd.PersistentFlags().StringVar(&cfgComplianceKeysPath, "keys-path", "", "Diretório de chaves dos tenants para cifra dos logs de compliance")
 	rootCmd.PersistentFlags().StringVar(&cfgLogLevel, "log-level", "info", "Nível de log (debug, info, warn, error)")
 	rootCmd.PersistentFlags().BoolVar(&cfgStructuredLogging, "structured-logging", true, "Usar logs estruturados (formato JSON)")
 	rootCmd.PersistentFlags().StringVar(&cfgMarket, "market", constants.MarketGlobal, fmt.Sprintf("Mercado (%s, %s, %s, etc)", constants.MarketAngola, constants.MarketBrazil, constants.MarketEU))
 	rootCmd.PersistentFlags().StringVar(&cfgTenantType, "tenant-type", constants.TenantFinancial, fmt.Sprintf("Tipo de tenant (%s, %s, %s, etc)", constants.TenantFinancial, constants.TenantRetail, constants.TenantHealthcare))
+	rootCmd.PersistentFlags().StringVar(&cfgProfile, "profile", "", fmt.Sprintf("Perfil de configuração a utilizar (padrão: perfil ativo ou $%s)", profiles.EnvProfile))
+	rootCmd.PersistentFlags().StringVar(&cfgConfigFile, "config-file", "", "Ficheiro de configuração (padrão: $XDG_CONFIG_HOME/innovabiz/observability-cli.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgTraceBackend, "trace-backend", tracing.BackendJaeger, fmt.Sprintf("Backend de consulta de traces (%s, %s)", tracing.BackendJaeger, tracing.BackendTempo))
+	rootCmd.PersistentFlags().StringVar(&cfgTraceBackendURL, "trace-backend-url", "http://localhost:16686", "URL da API HTTP do backend de traces (ex: http://tempo:3200)")
 	rootCmd.PersistentFlags().StringVar(&cfgHookType, "hook-type", constants.HookTypePrivilegeElevation, fmt.Sprintf("Tipo de hook (%s, %s, etc)", constants.HookTypePrivilegeElevation, constants.HookTypeMFAValidation))
+	rootCmd.PersistentFlags().StringVar(&cfgAdminURL, "admin-url", "", fmt.Sprintf("URL do endpoint administrativo do serviço (padrão: http://localhost:<metrics-port>%s)", adapter.DefaultRuntimeAdminPath))
 
 	// Flags específicas dos comandos de teste
 	testHookOperationsCmd.Flags().BoolVar(&simulateError, "simulate-error", false, "Simular erros nas operações")
 	testHookOperationsCmd.Flags().IntVar(&simulationCount, "count", 5, "Número de simulações a executar")
 	testHookOperationsCmd.Flags().IntVar(&simulationDelay, "delay", 200, "Delay entre simulações (ms)")
 
+	// Flags específicas dos comandos de logs
+	logsQueryCmd.Flags().StringVar(&queryTenant, "tenant", "", "Filtrar eventos pelo tenant")
+	logsQueryCmd.Flags().StringVar(&queryCategory, "category", "", "Filtrar pela categoria (audit, security)")
+	logsQueryCmd.Flags().StringVar(&querySince, "since", "", "Data inicial (AAAA-MM-DD)")
+	logsQueryCmd.Flags().StringVar(&queryUntil, "until", "", "Data final (AAAA-MM-DD)")
+	logsQueryCmd.Flags().StringVar(&queryContains, "contains", "", "Filtrar eventos que contenham o texto")
+	logsRotateKeyCmd.Flags().StringVar(&rotateTenant, "tenant", "", "Tenant cuja chave será rodada")
+	logsRotateKeyCmd.MarkFlagRequired("tenant")
+	logsReplayQuarantineCmd.Flags().StringVar(&quarantinePath, "quarantine-path", "", "Diretório de quarentena (padrão: <logs-path>/quarantine)")
+
+	// Flags específicas dos comandos de traces
+	traceConsultaCmd.Flags().StringVar(&traceService, "service", "bureau-credito", "Serviço cujos traces serão pesquisados")
+	traceConsultaCmd.Flags().DurationVar(&traceLookback, "lookback", 24*time.Hour, "Janela de pesquisa a partir de agora")
+	traceConsultaCmd.Flags().IntVar(&traceLimit, "limit", 20, "Número máximo de traces retornados")
+	traceGetCmd.Flags().BoolVar(&traceAllAttributes, "all-attributes", false, "Exibir todos os atributos dos spans e não apenas os de mercado e compliance")
+
+	// Flags específicas do comando de geração de dashboards
+	metricsDashboardsCmd.Flags().StringSliceVar(&dashboardMarkets, "markets", nil, "Mercados a gerar (padrão: todos)")
+	metricsDashboardsCmd.Flags().StringVar(&dashboardOutputDir, "output-dir", "dashboards", "Diretório de saída dos dashboards e regras de alerta")
+	metricsDashboardsCmd.Flags().StringVar(&dashboardDatasource, "datasource", adapter.DefaultDashboardDatasource, "UID da fonte de dados Prometheus no Grafana")
+	metricsDashboardsCmd.Flags().StringVar(&dashboardRefresh, "refresh", adapter.DefaultDashboardRefresh, "Intervalo de atualização dos dashboards")
+	metricsDashboardsCmd.Flags().Float64Var(&alertThresholds.HookErrorRatio, "alert-hook-error-ratio", alertThresholds.HookErrorRatio, "Proporção de erros de hook que dispara alerta")
+	metricsDashboardsCmd.Flags().Float64Var(&alertThresholds.HookLatencyP95Seconds, "alert-hook-latency", alertThresholds.HookLatencyP95Seconds, "Percentil 95 da duração dos hooks (segundos) que dispara alerta")
+	metricsDashboardsCmd.Flags().Float64Var(&alertThresholds.MaxActiveElevations, "alert-max-elevations", alertThresholds.MaxActiveElevations, "Elevações de privilégio ativas que disparam alerta")
+	metricsDashboardsCmd.Flags().Float64Var(&alertThresholds.MFAFailureRatio, "alert-mfa-failure-ratio", alertThresholds.MFAFailureRatio, "Proporção de falhas MFA que dispara alerta")
+	metricsDashboardsCmd.Flags().StringVar(&alertThresholds.For, "alert-for", alertThresholds.For, "Duração mínima da condição antes de disparar o alerta")
+
+	// Flags específicas dos comandos de configuração em tempo de execução
+	runtimeCmd.PersistentFlags().StringVar(&runtimeToken, "admin-token", "", fmt.Sprintf("Token do endpoint administrativo (padrão: $%s)", envAdminToken))
+	runtimeSetCmd.Flags().StringVar(&runtimeLogLevel, "level", "", "Nível de log (debug, info, warn, error)")
+	runtimeSetCmd.Flags().Float64Var(&runtimeSampleRate, "sample-rate", 0, "Taxa de amostragem dos traces (0.0-1.0)")
+	runtimeSetCmd.Flags().StringSliceVar(&runtimeEnableMetrics, "enable-metric", nil, "Métricas a ativar, pelo nome sem o namespace")
+	runtimeSetCmd.Flags().StringSliceVar(&runtimeDisableMetrics, "disable-metric", nil, "Métricas a desativar, pelo nome sem o namespace")
+	runtimeSetCmd.Flags().DurationVar(&runtimeRevertAfter, "revert-after", 0, "Repor a configuração anterior após a duração indicada (ex: 15m)")
+	runtimeSetCmd.Flags().StringVar(&runtimeReason, "reason", "", "Motivo registado na auditoria")
+	runtimeRevertCmd.Flags().StringVar(&runtimeReason, "reason", "", "Motivo registado na auditoria")
+
+	// Flags do comando slo
+	sloCmd.PersistentFlags().StringVar(&cfgSLOURL, "slo-url", "", fmt.Sprintf("URL do endpoint de SLOs do serviço (padrão: http://localhost:<metrics-port>%s)", adapter.DefaultSLOPath))
+
+	// Flags específicas dos comandos de perfil
+	configSaveCmd.Flags().BoolVar(&saveSetCurrent, "use", false, "Definir o perfil salvo como ativo")
+	configLintCmd.Flags().StringVar(&lintOutput, "output", lintOutputJSON, fmt.Sprintf("Formato dos resultados (%s, %s)", lintOutputJSON, lintOutputText))
+
 	// Estrutura de comandos
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSaveCmd)
+	configCmd.AddCommand(configUseCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configLintCmd)
 
 	rootCmd.AddCommand(testCmd)
 	testCmd.AddCommand(testHookOperationsCmd)
@@ -539,6 +610,25 @@ func init() {
 
 	rootCmd.AddCommand(metricsCmd)
 	metricsCmd.AddCommand(metricsExposeCmd)
+	metricsCmd.AddCommand(metricsDashboardsCmd)
+
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsQueryCmd)
+	logsCmd.AddCommand(logsRotateKeyCmd)
+	logsCmd.AddCommand(logsReplayQuarantineCmd)
+
+	rootCmd.AddCommand(traceCmd)
+	traceCmd.AddCommand(traceConsultaCmd)
+	traceCmd.AddCommand(traceGetCmd)
+
+	rootCmd.AddCommand(runtimeCmd)
+	runtimeCmd.AddCommand(runtimeGetCmd)
+	runtimeCmd.AddCommand(runtimeSetCmd)
+	runtimeCmd.AddCommand(runtimeRevertCmd)
+	runtimeCmd.AddCommand(runtimeHistoryCmd)
+
+	rootCmd.AddCommand(sloCmd)
+	sloCmd.AddCommand(sloStatusCmd)
 }
 
 func main() {
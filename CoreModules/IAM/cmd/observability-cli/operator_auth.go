@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Variáveis de ambiente da autenticação do operador no IAM, que não são guardadas nos perfis
+const (
+	// Token de acesso do operador emitido pelo IAM
+	envOperatorToken = "INNOVABIZ_OBS_OPERATOR_TOKEN"
+
+	// Endpoint de introspeção de tokens do IAM (RFC 7662)
+	envIntrospectionURL = "INNOVABIZ_OBS_IAM_INTROSPECTION_URL"
+
+	// Credenciais do cliente da CLI no endpoint de introspeção
+	envIntrospectionClientID     = "INNOVABIZ_OBS_IAM_CLIENT_ID"
+	envIntrospectionClientSecret = "INNOVABIZ_OBS_IAM_CLIENT_SECRET"
+
+	// Audiência exigida no token do operador
+	envOperatorAudience = "INNOVABIZ_OBS_IAM_AUDIENCE"
+)
+
+// errOperatorUnauthenticated indica que o operador não apresentou credenciais válidas do IAM
+var errOperatorUnauthenticated = errors.New("operador não autenticado")
+
+// tokenIntrospection é a resposta do endpoint de introspeção
+type tokenIntrospection struct {
+	Active   bool            `json:"active"`
+	Subject  string          `json:"sub"`
+	Username string          `json:"username"`
+	Expiry   int64           `json:"exp"`
+	Audience json.RawMessage `json:"aud"`
+}
+
+// hasAudience indica se a claim aud, texto ou lista, inclui a audiência
+func (t *tokenIntrospection) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(t.Audience, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(t.Audience, &list); err == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// operatorAuthenticator valida o token do operador no endpoint de introspeção do IAM
+type operatorAuthenticator struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	Audience     string // Audiência exigida no token; vazio não verifica
+	Client       *http.Client
+}
+
+// operatorAuthenticatorFromEnv cria o autenticador com a configuração do ambiente
+func operatorAuthenticatorFromEnv() *operatorAuthenticator {
+	return &operatorAuthenticator{
+		URL:          os.Getenv(envIntrospectionURL),
+		ClientID:     os.Getenv(envIntrospectionClientID),
+		ClientSecret: os.Getenv(envIntrospectionClientSecret),
+		Audience:     os.Getenv(envOperatorAudience),
+	}
+}
+
+// Authenticate valida o token no IAM e retorna o operador identificado pelo sujeito do token
+func (a *operatorAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("%w: token do operador não configurado ($%s)", errOperatorUnauthenticated, envOperatorToken)
+	}
+	if a.URL == "" {
+		return "", fmt.Errorf("%w: endpoint de introspeção do IAM não configurado ($%s)", errOperatorUnauthenticated, envIntrospectionURL)
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("falha ao criar pedido de introspeção: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.ClientID != "" {
+		req.SetBasicAuth(a.ClientID, a.ClientSecret)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("falha na introspeção do token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("introspeção do token retornou %d", resp.StatusCode)
+	}
+
+	var introspection tokenIntrospection
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&introspection); err != nil {
+		return "", fmt.Errorf("resposta de introspeção inválida: %w", err)
+	}
+	if !introspection.Active {
+		return "", fmt.Errorf("%w: token inativo", errOperatorUnauthenticated)
+	}
+	if introspection.Expiry != 0 && time.Now().Unix() >= introspection.Expiry {
+		return "", fmt.Errorf("%w: token expirado", errOperatorUnauthenticated)
+	}
+	if a.Audience != "" && !introspection.hasAudience(a.Audience) {
+		return "", fmt.Errorf("%w: audiência do token inválida", errOperatorUnauthenticated)
+	}
+
+	operator := introspection.Subject
+	if operator == "" {
+		operator = introspection.Username
+	}
+	if operator == "" {
+		return "", fmt.Errorf("%w: token sem sujeito", errOperatorUnauthenticated)
+	}
+	return operator, nil
+}
+
+// authenticateOperator identifica o operador pelo token do IAM em $INNOVABIZ_OBS_OPERATOR_TOKEN
+func authenticateOperator(ctx context.Context) (string, error) {
+	return operatorAuthenticatorFromEnv().Authenticate(ctx, os.Getenv(envOperatorToken))
+}
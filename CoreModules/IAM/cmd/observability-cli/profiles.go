@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/cmd/observability-cli/profiles"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Perfil e ficheiro de configuração selecionados
+	cfgProfile    string
+	cfgConfigFile string
+
+	// Flag do comando config save
+	saveSetCurrent bool
+)
+
+// configSaveCmd salva a configuração atual como perfil
+var configSaveCmd = &cobra.Command{
+	Use:   "save <perfil>",
+	Short: "Salvar a configuração atual como perfil",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		file, err := loadConfigFile()
+		if err != nil {
+			color.Red("Erro ao carregar ficheiro de configuração: %v", err)
+			os.Exit(1)
+		}
+
+		structuredLogging := cfgStructuredLogging
+		file.Profiles[name] = profiles.Profile{
+			Environment:        cfgEnvironment,
+			ServiceName:        cfgServiceName,
+			OTLPEndpoint:       cfgOTLPEndpoint,
+			MetricsPort:        cfgMetricsPort,
+			ComplianceLogsPath: cfgComplianceLogsPath,
+			ComplianceKeysPath: cfgComplianceKeysPath,
+			LogLevel:           cfgLogLevel,
+			StructuredLogging:  &structuredLogging,
+			Market:             cfgMarket,
+			TenantType:         cfgTenantType,
+			HookType:           cfgHookType,
+			TraceBackend:       cfgTraceBackend,
+			TraceBackendURL:    cfgTraceBackendURL,
+			AdminURL:           cfgAdminURL,
+		}
+
+		if saveSetCurrent || file.CurrentProfile == "" {
+			file.CurrentProfile = name
+		}
+
+		if err := file.Save(); err != nil {
+			color.Red("Erro ao salvar perfil: %v", err)
+			os.Exit(1)
+		}
+
+		color.Green("✓ Perfil '%s' salvo em %s", name, file.Path())
+	},
+}
+
+// configUseCmd define o perfil ativo
+var configUseCmd = &cobra.Command{
+	Use:   "use <perfil>",
+	Short: "Definir o perfil ativo",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		file, err := loadConfigFile()
+		if err != nil {
+			color.Red("Erro ao carregar ficheiro de configuração: %v", err)
+			os.Exit(1)
+		}
+
+		if _, ok := file.Profiles[name]; !ok {
+			color.Red("Perfil '%s' não encontrado", name)
+			os.Exit(1)
+		}
+
+		file.CurrentProfile = name
+		if err := file.Save(); err != nil {
+			color.Red("Erro ao salvar ficheiro de configuração: %v", err)
+			os.Exit(1)
+		}
+
+		color.Green("✓ Perfil ativo: %s", name)
+	},
+}
+
+// configListCmd lista os perfis disponíveis
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Listar perfis de configuração",
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := loadConfigFile()
+		if err != nil {
+			color.Red("Erro ao carregar ficheiro de configuração: %v", err)
+			os.Exit(1)
+		}
+
+		if len(file.Profiles) == 0 {
+			color.Yellow("Nenhum perfil configurado em %s", file.Path())
+			return
+		}
+
+		names := make([]string, 0, len(file.Profiles))
+		for name := range file.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		color.Cyan("Perfis em %s:", file.Path())
+		for _, name := range names {
+			p := file.Profiles[name]
+			marker := " "
+			if name == file.CurrentProfile {
+				marker = "*"
+			}
+			fmt.Printf("%s %-20s market=%s tenant-type=%s environment=%s otlp-endpoint=%s\n",
+				marker, name, p.Market, p.TenantType, p.Environment, p.OTLPEndpoint)
+		}
+	},
+}
+
+// loadConfigFile carrega o ficheiro de configuração selecionado, retornando um vazio se não existir
+func loadConfigFile() (*profiles.File, error) {
+	return profiles.Load(profiles.Path(cfgConfigFile))
+}
+
+// applyProfileAndEnv aplica o perfil ativo e as variáveis de ambiente às flags não informadas
+func applyProfileAndEnv(cmd *cobra.Command) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	overrides, err := file.Resolve(cfgProfile)
+	if err != nil {
+		return err
+	}
+
+	for _, override := range overrides {
+		flag := cmd.Flags().Lookup(override.Flag)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(override.Value); err != nil {
+			return fmt.Errorf("valor inválido para %s: %w", override.Flag, err)
+		}
+	}
+
+	return nil
+}
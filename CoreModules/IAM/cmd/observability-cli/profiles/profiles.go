@@ -0,0 +1,184 @@
+// Package profiles gere os perfis persistidos de configuração da CLI de observabilidade
+// e a resolução das flags globais a partir do ambiente e do perfil ativo.
+package profiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileName é o nome do ficheiro de configuração persistente da CLI
+	FileName = "observability-cli.yaml"
+
+	// Variáveis de ambiente para seleção de perfil e ficheiro de configuração
+	EnvProfile    = "INNOVABIZ_OBS_PROFILE"
+	EnvConfigFile = "INNOVABIZ_OBS_CONFIG_FILE"
+)
+
+// Profile representa um perfil persistido de configuração da CLI
+type Profile struct {
+	Environment        string `yaml:"environment,omitempty"`
+	ServiceName        string `yaml:"service_name,omitempty"`
+	OTLPEndpoint       string `yaml:"otlp_endpoint,omitempty"`
+	MetricsPort        int    `yaml:"metrics_port,omitempty"`
+	ComplianceLogsPath string `yaml:"logs_path,omitempty"`
+	ComplianceKeysPath string `yaml:"keys_path,omitempty"`
+	LogLevel           string `yaml:"log_level,omitempty"`
+	StructuredLogging  *bool  `yaml:"structured_logging,omitempty"`
+	Market             string `yaml:"market,omitempty"`
+	TenantType         string `yaml:"tenant_type,omitempty"`
+	HookType           string `yaml:"hook_type,omitempty"`
+	TraceBackend       string `yaml:"trace_backend,omitempty"`
+	TraceBackendURL    string `yaml:"trace_backend_url,omitempty"`
+	AdminURL           string `yaml:"admin_url,omitempty"`
+}
+
+// File representa o conteúdo do ficheiro de configuração da CLI
+type File struct {
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+
+	path string
+}
+
+// Override é o valor a aplicar a uma flag global não informada na linha de comando
+type Override struct {
+	Flag  string
+	Value string
+}
+
+// field associa uma flag global à variável de ambiente e ao campo do perfil
+type field struct {
+	flag  string
+	env   string
+	value func(p Profile) string
+}
+
+// fields define a ordem de resolução das flags globais: flag > ambiente > perfil > padrão
+var fields = []field{
+	{"environment", "INNOVABIZ_OBS_ENVIRONMENT", func(p Profile) string { return p.Environment }},
+	{"service-name", "INNOVABIZ_OBS_SERVICE_NAME", func(p Profile) string { return p.ServiceName }},
+	{"otlp-endpoint", "INNOVABIZ_OBS_OTLP_ENDPOINT", func(p Profile) string { return p.OTLPEndpoint }},
+	{"metrics-port", "INNOVABIZ_OBS_METRICS_PORT", func(p Profile) string {
+		if p.MetricsPort == 0 {
+			return ""
+		}
+		return strconv.Itoa(p.MetricsPort)
+	}},
+	{"logs-path", "INNOVABIZ_OBS_LOGS_PATH", func(p Profile) string { return p.ComplianceLogsPath }},
+	{"keys-path", "INNOVABIZ_OBS_KEYS_PATH", func(p Profile) string { return p.ComplianceKeysPath }},
+	{"log-level", "INNOVABIZ_OBS_LOG_LEVEL", func(p Profile) string { return p.LogLevel }},
+	{"structured-logging", "INNOVABIZ_OBS_STRUCTURED_LOGGING", func(p Profile) string {
+		if p.StructuredLogging == nil {
+			return ""
+		}
+		return strconv.FormatBool(*p.StructuredLogging)
+	}},
+	{"market", "INNOVABIZ_OBS_MARKET", func(p Profile) string { return p.Market }},
+	{"tenant-type", "INNOVABIZ_OBS_TENANT_TYPE", func(p Profile) string { return p.TenantType }},
+	{"hook-type", "INNOVABIZ_OBS_HOOK_TYPE", func(p Profile) string { return p.HookType }},
+	{"trace-backend", "INNOVABIZ_OBS_TRACE_BACKEND", func(p Profile) string { return p.TraceBackend }},
+	{"trace-backend-url", "INNOVABIZ_OBS_TRACE_BACKEND_URL", func(p Profile) string { return p.TraceBackendURL }},
+	{"admin-url", "INNOVABIZ_OBS_ADMIN_URL", func(p Profile) string { return p.AdminURL }},
+}
+
+// Path retorna o caminho do ficheiro de configuração persistente
+// O caminho explícito (--config-file) prevalece sobre a variável de ambiente e o diretório de configuração do utilizador
+func Path(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		} else {
+			configHome = os.TempDir()
+		}
+	}
+
+	return filepath.Join(configHome, "innovabiz", FileName)
+}
+
+// Load carrega o ficheiro de configuração, retornando um vazio se não existir
+func Load(path string) (*File, error) {
+	file := &File{Profiles: make(map[string]Profile), path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("formato inválido em %s: %w", path, err)
+	}
+
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]Profile)
+	}
+
+	return file, nil
+}
+
+// Path retorna o caminho de onde o ficheiro foi carregado
+func (f *File) Path() string {
+	return f.path
+}
+
+// Save persiste o ficheiro de configuração no caminho de onde foi carregado
+func (f *File) Save() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.path, data, 0600)
+}
+
+// Resolve retorna os valores das flags globais definidos no ambiente ou no perfil selecionado
+// O perfil é o indicado (--profile), o da variável de ambiente ou o perfil ativo do ficheiro
+func (f *File) Resolve(profileName string) ([]Override, error) {
+	if profileName == "" {
+		profileName = os.Getenv(EnvProfile)
+	}
+	if profileName == "" {
+		profileName = f.CurrentProfile
+	}
+
+	var profile Profile
+	if profileName != "" {
+		p, ok := f.Profiles[profileName]
+		if !ok {
+			return nil, fmt.Errorf("perfil '%s' não encontrado em %s", profileName, f.path)
+		}
+		profile = p
+	}
+
+	var overrides []Override
+	for _, field := range fields {
+		value := os.Getenv(field.env)
+		if value == "" {
+			value = field.value(profile)
+		}
+		if value != "" {
+			overrides = append(overrides, Override{Flag: field.flag, Value: value})
+		}
+	}
+	return overrides, nil
+}
@@ -0,0 +1,169 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limparAmbiente remove as variáveis de ambiente da CLI durante o teste
+func limparAmbiente(t *testing.T) {
+	t.Helper()
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvConfigFile, "")
+	for _, field := range fields {
+		t.Setenv(field.env, "")
+	}
+}
+
+// overridesMap converte os valores resolvidos num mapa flag -> valor
+func overridesMap(overrides []Override) map[string]string {
+	values := make(map[string]string, len(overrides))
+	for _, override := range overrides {
+		values[override.Flag] = override.Value
+	}
+	return values
+}
+
+func TestPath(t *testing.T) {
+	limparAmbiente(t)
+	t.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+
+	assert.Equal(t, filepath.Join("/etc/xdg", "innovabiz", FileName), Path(""))
+
+	t.Setenv(EnvConfigFile, "/srv/obs.yaml")
+	assert.Equal(t, "/srv/obs.yaml", Path(""))
+	assert.Equal(t, "/tmp/cli.yaml", Path("/tmp/cli.yaml"), "--config-file prevalece sobre o ambiente")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inexistente.yaml")
+
+	file, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, file.CurrentProfile)
+	assert.NotNil(t, file.Profiles)
+	assert.Equal(t, path, file.Path())
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte("profiles: [1, 2"), 0600))
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "formato inválido em "+path)
+
+	require.NoError(t, os.WriteFile(path, []byte("current_profile: ao\n"), 0600))
+	file, err := Load(path)
+	require.NoError(t, err)
+	assert.NotNil(t, file.Profiles, "um ficheiro sem perfis é carregado com o mapa vazio")
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "innovabiz", FileName)
+	file, err := Load(path)
+	require.NoError(t, err)
+
+	structured := false
+	file.CurrentProfile = "angola-prod"
+	file.Profiles["angola-prod"] = Profile{
+		Environment:       "production",
+		MetricsPort:       9191,
+		StructuredLogging: &structured,
+		Market:            "Angola",
+		TraceBackendURL:   "http://tempo:3200",
+	}
+	require.NoError(t, file.Save())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "o ficheiro pode conter endpoints internos e só é legível pelo utilizador")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "current_profile: angola-prod")
+	assert.Contains(t, string(data), "trace_backend_url: http://tempo:3200")
+	assert.NotContains(t, string(data), "service_name", "campos vazios são omitidos")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, file.Profiles, loaded.Profiles)
+	assert.Equal(t, "angola-prod", loaded.CurrentProfile)
+}
+
+// ficheiroPerfis tem um perfil ativo de Angola e um perfil do Brasil
+func ficheiroPerfis() *File {
+	structured := true
+	return &File{
+		CurrentProfile: "ao",
+		Profiles: map[string]Profile{
+			"ao": {Environment: "production", Market: "Angola", MetricsPort: 9191, StructuredLogging: &structured},
+			"br": {Environment: "staging", Market: "Brazil", TenantType: "financial"},
+		},
+		path: "/tmp/" + FileName,
+	}
+}
+
+func TestResolveCurrentProfile(t *testing.T) {
+	limparAmbiente(t)
+
+	overrides, err := ficheiroPerfis().Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"environment":        "production",
+		"market":             "Angola",
+		"metrics-port":       "9191",
+		"structured-logging": "true",
+	}, overridesMap(overrides), "campos vazios do perfil não alteram os padrões das flags")
+
+	// Os valores seguem a ordem das flags globais
+	assert.Equal(t, "environment", overrides[0].Flag)
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	limparAmbiente(t)
+
+	// O perfil da variável de ambiente prevalece sobre o perfil ativo
+	t.Setenv(EnvProfile, "br")
+	overrides, err := ficheiroPerfis().Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, "Brazil", overridesMap(overrides)["market"])
+
+	// --profile prevalece sobre a variável de ambiente
+	overrides, err = ficheiroPerfis().Resolve("ao")
+	require.NoError(t, err)
+	assert.Equal(t, "Angola", overridesMap(overrides)["market"])
+
+	// As variáveis de ambiente de cada flag prevalecem sobre o perfil
+	t.Setenv("INNOVABIZ_OBS_MARKET", "EU")
+	t.Setenv("INNOVABIZ_OBS_ADMIN_URL", "https://iam-admin.innovabiz.ao")
+	overrides, err = ficheiroPerfis().Resolve("ao")
+	require.NoError(t, err)
+	values := overridesMap(overrides)
+	assert.Equal(t, "EU", values["market"])
+	assert.Equal(t, "https://iam-admin.innovabiz.ao", values["admin-url"])
+	assert.Equal(t, "production", values["environment"])
+}
+
+func TestResolveWithoutProfile(t *testing.T) {
+	limparAmbiente(t)
+	t.Setenv("INNOVABIZ_OBS_TRACE_BACKEND", "tempo")
+
+	file := ficheiroPerfis()
+	file.CurrentProfile = ""
+	overrides, err := file.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, []Override{{Flag: "trace-backend", Value: "tempo"}}, overrides)
+}
+
+func TestResolveUnknownProfile(t *testing.T) {
+	limparAmbiente(t)
+
+	_, err := ficheiroPerfis().Resolve("mz")
+	require.Error(t, err)
+	assert.EqualError(t, err, "perfil 'mz' não encontrado em /tmp/"+FileName)
+}
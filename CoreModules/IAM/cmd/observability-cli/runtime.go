@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/spf13/cobra"
+)
+
+// Variável de ambiente com o token do endpoint administrativo, que não é guardado nos perfis
+const envAdminToken = "INNOVABIZ_OBS_ADMIN_TOKEN"
+
+var (
+	// Endereço e token do endpoint administrativo de observabilidade
+	cfgAdminURL   string
+	runtimeToken  string
+	runtimeReason string
+
+	// Flags do comando runtime set
+	runtimeLogLevel       string
+	runtimeSampleRate     float64
+	runtimeEnableMetrics  []string
+	runtimeDisableMetrics []string
+	runtimeRevertAfter    time.Duration
+)
+
+// runtimeConfigResponse é a resposta do endpoint administrativo
+type runtimeConfigResponse struct {
+	Config  adapter.RuntimeConfig        `json:"config"`
+	History []adapter.RuntimeConfigAudit `json:"history"`
+}
+
+// runtimeCmd agrupa os comandos de configuração em tempo de execução
+var runtimeCmd = &cobra.Command{
+	Use:   "runtime",
+	Short: "Alterar nível de log, amostragem e métricas de um serviço em execução",
+	Long: fmt.Sprintf(`Consulta e altera a configuração de observabilidade de um serviço em execução
+pelo endpoint administrativo do adaptador, sem reiniciar o serviço.
+
+O token administrativo é lido de --admin-token ou $%s. Cada alteração é
+auditada no serviço com o operador associado ao token e o motivo indicado.`, envAdminToken),
+}
+
+// runtimeGetCmd exibe a configuração em vigor
+var runtimeGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Exibir a configuração em vigor",
+	Run: func(cmd *cobra.Command, args []string) {
+		resp := runtimeRequest(http.MethodGet, "", nil)
+		printRuntimeConfig(resp.Config)
+	},
+}
+
+// runtimeHistoryCmd exibe o histórico das alterações
+var runtimeHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Exibir o histórico das alterações da configuração",
+	Run: func(cmd *cobra.Command, args []string) {
+		resp := runtimeRequest(http.MethodGet, "", nil)
+		if len(resp.History) == 0 {
+			color.Yellow("Nenhuma alteração registada desde o arranque do serviço")
+			return
+		}
+
+		for _, audit := range resp.History {
+			action := "alteração"
+			if audit.Revert {
+				action = "reposição"
+			} else if audit.RevertAfter != "" {
+				action = fmt.Sprintf("alteração temporária (%s)", audit.RevertAfter)
+			}
+			color.Cyan("%s  %s  %s", audit.Time.Local().Format(time.RFC3339), audit.Operator, action)
+			if audit.Reason != "" {
+				fmt.Printf("  Motivo: %s\n", audit.Reason)
+			}
+			fmt.Printf("  Nível de log: %s → %s\n", audit.Previous.LogLevel, audit.Current.LogLevel)
+			fmt.Printf("  Amostragem: %g → %g\n", audit.Previous.TraceSampleRate, audit.Current.TraceSampleRate)
+			fmt.Printf("  Métricas desativadas: %s → %s\n",
+				formatMetricList(audit.Previous.DisabledMetrics), formatMetricList(audit.Current.DisabledMetrics))
+		}
+	},
+}
+
+// runtimeSetCmd altera a configuração em vigor
+var runtimeSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Alterar o nível de log, a amostragem ou as métricas",
+	Long: `Altera a configuração de observabilidade do serviço. Com --revert-after, a
+configuração anterior é reposta automaticamente no fim do prazo; em produção, o
+nível debug só é aceite desta forma.`,
+	Example: `  observability-cli runtime set --level debug --revert-after 15m --reason "INC-1234"
+  observability-cli runtime set --sample-rate 0.1 --disable-metric payment_gateway_transaction_count`,
+	Run: func(cmd *cobra.Command, args []string) {
+		body := map[string]interface{}{}
+		if cmd.Flags().Changed("level") {
+			body["log_level"] = runtimeLogLevel
+		}
+		if cmd.Flags().Changed("sample-rate") {
+			body["trace_sample_rate"] = runtimeSampleRate
+		}
+		if len(runtimeEnableMetrics) > 0 || len(runtimeDisableMetrics) > 0 {
+			metrics := map[string]bool{}
+			for _, name := range runtimeEnableMetrics {
+				metrics[name] = true
+			}
+			for _, name := range runtimeDisableMetrics {
+				metrics[name] = false
+			}
+			body["metrics"] = metrics
+		}
+		if len(body) == 0 {
+			color.Red("Indique pelo menos uma alteração (--level, --sample-rate, --enable-metric ou --disable-metric)")
+			os.Exit(1)
+		}
+		if runtimeRevertAfter > 0 {
+			body["revert_after"] = runtimeRevertAfter.String()
+		}
+		if runtimeReason != "" {
+			body["reason"] = runtimeReason
+		}
+
+		resp := runtimeRequest(http.MethodPatch, "", body)
+		color.Green("✓ Configuração alterada")
+		printRuntimeConfig(resp.Config)
+	},
+}
+
+// runtimeRevertCmd repõe a configuração anterior à alteração temporária ativa
+var runtimeRevertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "Repor já a configuração anterior à alteração temporária",
+	Run: func(cmd *cobra.Command, args []string) {
+		query := ""
+		if runtimeReason != "" {
+			query = "?reason=" + url.QueryEscape(runtimeReason)
+		}
+		resp := runtimeRequest(http.MethodDelete, query, nil)
+		color.Green("✓ Configuração reposta")
+		printRuntimeConfig(resp.Config)
+	},
+}
+
+// runtimeRequest executa um pedido no endpoint administrativo, terminando a CLI em caso de erro
+func runtimeRequest(method, query string, body interface{}) runtimeConfigResponse {
+	token := runtimeToken
+	if token == "" {
+		token = os.Getenv(envAdminToken)
+	}
+	if token == "" {
+		color.Red("Token administrativo não configurado (--admin-token ou $%s)", envAdminToken)
+		os.Exit(1)
+	}
+
+	endpoint := resolveAdminURL()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var resp runtimeConfigResponse
+	if err := doAdminJSON(ctx, &http.Client{}, method, endpoint+query, token, body, &resp); err != nil {
+		color.Red("Erro no endpoint administrativo %s: %v", endpoint, err)
+		os.Exit(1)
+	}
+	return resp
+}
+
+// resolveAdminURL retorna o endereço do endpoint administrativo: --admin-url ou a porta de métricas local
+func resolveAdminURL() string {
+	if cfgAdminURL != "" {
+		return strings.TrimRight(cfgAdminURL, "/")
+	}
+	return fmt.Sprintf("http://localhost:%d%s", cfgMetricsPort, adapter.DefaultRuntimeAdminPath)
+}
+
+// doAdminJSON envia o pedido, autenticado quando há token, e decodifica a resposta JSON
+func doAdminJSON(ctx context.Context, client *http.Client, method, endpoint, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("erro ao codificar pedido: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro na requisição HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+	return nil
+}
+
+// printRuntimeConfig exibe a configuração em vigor
+func printRuntimeConfig(config adapter.RuntimeConfig) {
+	fmt.Printf("Nível de log:         %s\n", config.LogLevel)
+	fmt.Printf("Amostragem de traces: %g\n", config.TraceSampleRate)
+	fmt.Printf("Métricas desativadas: %s\n", formatMetricList(config.DisabledMetrics))
+	if config.RevertAt != nil {
+		color.Yellow("Reposição automática: %s (em %s)", config.RevertAt.Local().Format(time.RFC3339),
+			time.Until(*config.RevertAt).Round(time.Second))
+	}
+}
+
+// formatMetricList formata uma lista de métricas para exibição
+func formatMetricList(metrics []string) string {
+	if len(metrics) == 0 {
+		return "nenhuma"
+	}
+	return strings.Join(metrics, ", ")
+}
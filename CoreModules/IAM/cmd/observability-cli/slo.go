@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/spf13/cobra"
+)
+
+// Endereço do endpoint de estado dos SLOs
+var cfgSLOURL string
+
+// sloStatusResponse é a resposta do endpoint de estado dos SLOs
+type sloStatusResponse struct {
+	Service string              `json:"service"`
+	SLOs    []adapter.SLOStatus `json:"slos"`
+}
+
+// sloCmd agrupa os comandos de SLOs
+var sloCmd = &cobra.Command{
+	Use:   "slo",
+	Short: "Consultar os SLOs e o burn rate do orçamento de erro",
+	Long: `Consulta os SLOs avaliados pelo adaptador de um serviço em execução: o
+cumprimento do objetivo no período, o orçamento de erro restante, o burn rate por
+janela e os alertas multi-janela ativos, com os traces dos eventos fora do objetivo.`,
+}
+
+// sloStatusCmd exibe o estado dos SLOs por mercado
+var sloStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Exibir o estado dos SLOs por mercado",
+	Long: `Exibe o estado dos SLOs na última avaliação do serviço, agrupado por mercado.
+Sem --market são exibidos todos os mercados com eventos.`,
+	Example: `  observability-cli slo status
+  observability-cli slo status --market Angola --slo-url http://iam-hooks:9090/slo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		endpoint := resolveSLOURL()
+		if cmd.Flags().Changed("market") {
+			endpoint += "?market=" + url.QueryEscape(cfgMarket)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var resp sloStatusResponse
+		if err := doAdminJSON(ctx, &http.Client{}, http.MethodGet, endpoint, "", nil, &resp); err != nil {
+			color.Red("Erro no endpoint de SLOs %s: %v", endpoint, err)
+			os.Exit(1)
+		}
+		if len(resp.SLOs) == 0 {
+			color.Yellow("Nenhum SLO avaliado para o serviço %s", resp.Service)
+			return
+		}
+
+		color.Cyan("SLOs do serviço %s (avaliados em %s)", resp.Service, resp.SLOs[0].EvaluatedAt.Local().Format(time.RFC3339))
+		market := ""
+		for _, status := range resp.SLOs {
+			if status.Market != market {
+				market = status.Market
+				color.Cyan("\nMercado %s", market)
+			}
+			printSLOStatus(status)
+		}
+	},
+}
+
+// resolveSLOURL retorna o endereço do endpoint de estado dos SLOs: --slo-url ou a porta de métricas local
+func resolveSLOURL() string {
+	if cfgSLOURL != "" {
+		return strings.TrimRight(cfgSLOURL, "/")
+	}
+	return fmt.Sprintf("http://localhost:%d%s", cfgMetricsPort, adapter.DefaultSLOPath)
+}
+
+// printSLOStatus exibe o estado de um SLO, destacando os alertas ativos
+func printSLOStatus(status adapter.SLOStatus) {
+	severities := status.FiringSeverities()
+	title := fmt.Sprintf("  %s (objetivo %g%% abaixo de %g)", status.SLO, status.Objective*100, status.Threshold)
+	switch {
+	case len(severities) == 0:
+		color.Green("%s  OK", title)
+	case containsString(severities, "critical"):
+		color.Red("%s  %s", title, strings.ToUpper(strings.Join(severities, ", ")))
+	default:
+		color.Yellow("%s  %s", title, strings.ToUpper(strings.Join(severities, ", ")))
+	}
+	if status.Description != "" {
+		fmt.Printf("    %s\n", status.Description)
+	}
+
+	fmt.Printf("    Cumprimento (%s): %.3f%% (%g de %g eventos fora do objetivo)\n",
+		status.Period, status.Attainment*100, status.BadEvents, status.TotalEvents)
+	fmt.Printf("    Orçamento de erro restante: %.1f%%\n", status.ErrorBudgetRemaining*100)
+
+	rates := make([]string, 0, len(status.BurnRates))
+	for _, rate := range status.BurnRates {
+		entry := fmt.Sprintf("%s %.2f", rate.Window, rate.BurnRate)
+		if rate.Covered != rate.Window {
+			entry += fmt.Sprintf(" (histórico %s)", rate.Covered)
+		}
+		rates = append(rates, entry)
+	}
+	fmt.Printf("    Burn rate: %s\n", strings.Join(rates, " | "))
+
+	for _, alert := range status.Alerts {
+		if alert.Firing {
+			fmt.Printf("    Alerta %s: burn rate ≥ %g em %s e %s\n", alert.Severity, alert.BurnRate, alert.LongWindow, alert.ShortWindow)
+		}
+	}
+	for _, exemplar := range status.Exemplars {
+		fmt.Printf("    Trace %s  %g  (observability-cli trace get %s)\n", exemplar.TraceID, exemplar.Value, exemplar.TraceID)
+	}
+}
+
+// containsString indica se a lista contém o valor
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
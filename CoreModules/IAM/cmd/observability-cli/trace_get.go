@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/cmd/observability-cli/tracing"
+	"github.com/spf13/cobra"
+)
+
+// Flags do comando trace get
+var traceAllAttributes bool
+
+// traceGetCmd obtém um trace e exibe a árvore de spans
+var traceGetCmd = &cobra.Command{
+	Use:   "get <trace-id>",
+	Short: "Obter um trace e exibir a árvore de spans",
+	Long: `Obtém um trace do backend de tracing (Jaeger ou Tempo) e exibe a árvore de
+spans com a duração de cada span, a sua posição na linha temporal do trace e os
+atributos de mercado e compliance (market, tenant, hook_type, compliance_*).
+
+Os spans com erro são destacados a vermelho com a mensagem de estado, o que
+permite diagnosticar falhas dos hooks de compliance sem abrir a interface web.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		traceID, err := tracing.ParseTraceID(args[0])
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		backend, err := tracing.NewBackend(cfgTraceBackend, cfgTraceBackendURL)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		detail, err := backend.GetTrace(ctx, traceID)
+		if err != nil {
+			color.Red("Erro ao obter trace em %s: %v", cfgTraceBackendURL, err)
+			os.Exit(1)
+		}
+
+		printTraceTree(detail, traceAllAttributes)
+	},
+}
+
+// printTraceTree exibe o cabeçalho do trace e a árvore de spans, com os spans com erro a vermelho
+func printTraceTree(detail *tracing.Detail, allAttributes bool) {
+	header, lines := tracing.Render(detail, allAttributes)
+	if header.Error {
+		color.Red("%s", header.Text)
+	} else {
+		color.Cyan(header.Text)
+	}
+
+	for _, line := range lines {
+		if line.Error {
+			color.Red("%s", line.Text)
+		} else {
+			fmt.Println(line.Text)
+		}
+	}
+}
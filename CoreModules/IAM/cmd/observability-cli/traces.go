@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/cmd/observability-cli/tracing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Backend de consulta de traces
+	cfgTraceBackend    string
+	cfgTraceBackendURL string
+
+	// Flags do comando trace consulta
+	traceService  string
+	traceLookback time.Duration
+	traceLimit    int
+)
+
+// traceCmd representa o comando para consultar traces
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: "Consultar traces no backend de tracing",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// traceConsultaCmd pesquisa os traces de uma consulta do Bureau de Crédito
+var traceConsultaCmd = &cobra.Command{
+	Use:   "consulta <consulta-id>",
+	Short: "Pesquisar os traces de uma consulta do Bureau de Crédito",
+	Long: `Pesquisa no backend de tracing (Jaeger ou Tempo) os traces com o atributo
+consulta.id, propagado via baggage do orquestrador até às chamadas aos provedores.
+
+Para cada trace são exibidos o início, a duração, os provedores consultados e
+o número de spans com erro.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		backend, err := tracing.NewBackend(cfgTraceBackend, cfgTraceBackendURL)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		end := time.Now()
+		query := tracing.Query{
+			Service: traceService,
+			Tags:    map[string]string{tracing.ConsultaIDAttribute: args[0]},
+			Start:   end.Add(-traceLookback),
+			End:     end,
+			Limit:   traceLimit,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		traces, err := backend.SearchTraces(ctx, query)
+		if err != nil {
+			color.Red("Erro ao pesquisar traces em %s: %v", cfgTraceBackendURL, err)
+			os.Exit(1)
+		}
+
+		if len(traces) == 0 {
+			color.Yellow("Nenhum trace encontrado para a consulta %s nas últimas %s", args[0], traceLookback)
+			return
+		}
+
+		sort.Slice(traces, func(i, j int) bool { return traces[i].Start.Before(traces[j].Start) })
+
+		color.Cyan("%d trace(s) da consulta %s:", len(traces), args[0])
+		for _, t := range traces {
+			spans := "-"
+			if t.SpanCount > 0 {
+				spans = strconv.Itoa(t.SpanCount)
+			}
+			line := fmt.Sprintf("%s  %s  %-10s spans=%-4s %s/%s",
+				t.TraceID, t.Start.Format(time.RFC3339), t.Duration.Round(time.Millisecond), spans, t.RootService, t.RootName)
+			if len(t.Providers) > 0 {
+				line += " provedores=" + strings.Join(t.Providers, ",")
+			}
+			if t.Errors > 0 {
+				color.Red("%s erros=%d", line, t.Errors)
+				continue
+			}
+			fmt.Println(line)
+		}
+	},
+}
@@ -0,0 +1,449 @@
+// Package tracing consulta traces nos backends de tracing (Jaeger e Grafana Tempo)
+// e organiza os spans de um trace em árvore para exibição no terminal.
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Backends de tracing suportados
+	BackendJaeger = "jaeger"
+	BackendTempo  = "tempo"
+
+	// Atributos propagados via baggage pelo Bureau de Crédito
+	ConsultaIDAttribute = "consulta.id"
+	ProviderAttribute   = "provider.id"
+)
+
+// Query define os critérios de pesquisa de traces
+type Query struct {
+	Service string
+	Tags    map[string]string
+	Start   time.Time
+	End     time.Time
+	Limit   int
+}
+
+// Summary resume um trace encontrado no backend
+type Summary struct {
+	TraceID     string
+	RootService string
+	RootName    string
+	Start       time.Time
+	Duration    time.Duration
+	SpanCount   int      // Zero quando o backend não informa os spans na pesquisa
+	Errors      int      // Spans com erro
+	Providers   []string // Provedores consultados (atributo provider.id)
+}
+
+// Span é um span de um trace obtido do backend
+type Span struct {
+	SpanID        string
+	ParentID      string // Vazio no span raiz
+	Service       string
+	Name          string
+	Start         time.Time
+	Duration      time.Duration
+	Attributes    map[string]string
+	Error         bool
+	StatusMessage string
+}
+
+// Detail contém todos os spans de um trace
+type Detail struct {
+	TraceID string
+	Spans   []Span
+}
+
+// Backend pesquisa e obtém traces num backend de tracing
+type Backend interface {
+	SearchTraces(ctx context.Context, query Query) ([]Summary, error)
+	GetTrace(ctx context.Context, traceID string) (*Detail, error)
+}
+
+// NewBackend cria o cliente do backend de tracing configurado
+func NewBackend(kind, baseURL string) (Backend, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("URL do backend de tracing não configurada (--trace-backend-url)")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	switch strings.ToLower(kind) {
+	case BackendJaeger:
+		return &jaegerBackend{baseURL: baseURL, client: client}, nil
+	case BackendTempo:
+		return &tempoBackend{baseURL: baseURL, client: client}, nil
+	default:
+		return nil, fmt.Errorf("backend de tracing não suportado: %s (use %s ou %s)", kind, BackendJaeger, BackendTempo)
+	}
+}
+
+// getJSON executa um GET no backend e decodifica a resposta JSON
+func getJSON(ctx context.Context, client *http.Client, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro na requisição HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta: %w", err)
+	}
+	return nil
+}
+
+// jaegerBackend pesquisa traces na API HTTP de consulta do Jaeger
+type jaegerBackend struct {
+	baseURL string
+	client  *http.Client
+}
+
+// jaegerTracesResponse é a resposta de /api/traces do Jaeger
+type jaegerTracesResponse struct {
+	Data []jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"` // Microssegundos desde a época
+	Duration      int64             `json:"duration"`  // Microssegundos
+	Tags          []jaegerTag       `json:"tags"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"` // CHILD_OF ou FOLLOWS_FROM
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerTag struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type jaegerProcess struct {
+	ServiceName string `json:"serviceName"`
+}
+
+// SearchTraces pesquisa traces por serviço e atributos
+func (b *jaegerBackend) SearchTraces(ctx context.Context, query Query) ([]Summary, error) {
+	tags, err := json.Marshal(query.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("service", query.Service)
+	params.Set("tags", string(tags))
+	params.Set("start", strconv.FormatInt(query.Start.UnixMicro(), 10))
+	params.Set("end", strconv.FormatInt(query.End.UnixMicro(), 10))
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var resp jaegerTracesResponse
+	if err := getJSON(ctx, b.client, b.baseURL+"/api/traces?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(resp.Data))
+	for _, t := range resp.Data {
+		summaries = append(summaries, summarizeJaegerTrace(t))
+	}
+	return summaries, nil
+}
+
+// GetTrace obtém todos os spans de um trace
+func (b *jaegerBackend) GetTrace(ctx context.Context, traceID string) (*Detail, error) {
+	var resp jaegerTracesResponse
+	if err := getJSON(ctx, b.client, b.baseURL+"/api/traces/"+url.PathEscape(traceID), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("trace %s não encontrado", traceID)
+	}
+
+	t := resp.Data[0]
+	detail := &Detail{TraceID: t.TraceID, Spans: make([]Span, 0, len(t.Spans))}
+	for _, span := range t.Spans {
+		s := Span{
+			SpanID:     span.SpanID,
+			Service:    t.Processes[span.ProcessID].ServiceName,
+			Name:       span.OperationName,
+			Start:      time.UnixMicro(span.StartTime),
+			Duration:   time.Duration(span.Duration) * time.Microsecond,
+			Attributes: make(map[string]string, len(span.Tags)),
+		}
+		for _, ref := range span.References {
+			if ref.RefType == "CHILD_OF" || s.ParentID == "" {
+				s.ParentID = ref.SpanID
+			}
+		}
+		for _, tag := range span.Tags {
+			value := fmt.Sprint(tag.Value)
+			switch tag.Key {
+			case "error":
+				s.Error = s.Error || value == "true"
+			case "otel.status_code":
+				s.Error = s.Error || value == "ERROR"
+			case "otel.status_description":
+				s.StatusMessage = value
+			default:
+				s.Attributes[tag.Key] = value
+			}
+		}
+		detail.Spans = append(detail.Spans, s)
+	}
+	return detail, nil
+}
+
+// summarizeJaegerTrace resume um trace do Jaeger a partir dos seus spans
+func summarizeJaegerTrace(t jaegerTrace) Summary {
+	summary := Summary{TraceID: t.TraceID, SpanCount: len(t.Spans)}
+	providers := make(map[string]bool)
+	var first, last int64
+
+	for _, span := range t.Spans {
+		if first == 0 || span.StartTime < first {
+			first = span.StartTime
+		}
+		if end := span.StartTime + span.Duration; end > last {
+			last = end
+		}
+		if len(span.References) == 0 {
+			summary.RootName = span.OperationName
+			summary.RootService = t.Processes[span.ProcessID].ServiceName
+		}
+
+		for _, tag := range span.Tags {
+			switch tag.Key {
+			case "error":
+				if v, ok := tag.Value.(bool); ok && v {
+					summary.Errors++
+				}
+			case "otel.status_code":
+				if v, ok := tag.Value.(string); ok && v == "ERROR" {
+					summary.Errors++
+				}
+			case ProviderAttribute:
+				if v, ok := tag.Value.(string); ok && v != "" && !providers[v] {
+					providers[v] = true
+					summary.Providers = append(summary.Providers, v)
+				}
+			}
+		}
+	}
+
+	summary.Start = time.UnixMicro(first)
+	summary.Duration = time.Duration(last-first) * time.Microsecond
+	sort.Strings(summary.Providers)
+	return summary
+}
+
+// tempoBackend pesquisa traces na API HTTP de pesquisa do Grafana Tempo
+type tempoBackend struct {
+	baseURL string
+	client  *http.Client
+}
+
+// tempoSearchResponse é a resposta de /api/search do Tempo
+type tempoSearchResponse struct {
+	Traces []struct {
+		TraceID           string `json:"traceID"`
+		RootServiceName   string `json:"rootServiceName"`
+		RootTraceName     string `json:"rootTraceName"`
+		StartTimeUnixNano string `json:"startTimeUnixNano"`
+		DurationMs        int64  `json:"durationMs"`
+	} `json:"traces"`
+}
+
+// SearchTraces pesquisa traces por serviço e atributos
+func (b *tempoBackend) SearchTraces(ctx context.Context, query Query) ([]Summary, error) {
+	// Tempo recebe os atributos em formato logfmt
+	tags := make([]string, 0, len(query.Tags)+1)
+	if query.Service != "" {
+		tags = append(tags, fmt.Sprintf("service.name=%q", query.Service))
+	}
+	for key, value := range query.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%q", key, value))
+	}
+	sort.Strings(tags)
+
+	params := url.Values{}
+	params.Set("tags", strings.Join(tags, " "))
+	params.Set("start", strconv.FormatInt(query.Start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(query.End.Unix(), 10))
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	var resp tempoSearchResponse
+	if err := getJSON(ctx, b.client, b.baseURL+"/api/search?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(resp.Traces))
+	for _, t := range resp.Traces {
+		startNano, _ := strconv.ParseInt(t.StartTimeUnixNano, 10, 64)
+		summaries = append(summaries, Summary{
+			TraceID:     t.TraceID,
+			RootService: t.RootServiceName,
+			RootName:    t.RootTraceName,
+			Start:       time.Unix(0, startNano),
+			Duration:    time.Duration(t.DurationMs) * time.Millisecond,
+		})
+	}
+	return summaries, nil
+}
+
+// tempoTraceResponse é a resposta de /api/traces/<id> do Tempo, no formato OTLP JSON
+type tempoTraceResponse struct {
+	Batches []struct {
+		Resource struct {
+			Attributes []tempoAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []tempoScopeSpans `json:"scopeSpans"`
+		// Versões anteriores do Tempo usam o nome antigo do campo
+		InstrumentationLibrarySpans []tempoScopeSpans `json:"instrumentationLibrarySpans"`
+	} `json:"batches"`
+}
+
+type tempoScopeSpans struct {
+	Spans []tempoSpan `json:"spans"`
+}
+
+type tempoSpan struct {
+	SpanID            string           `json:"spanId"`
+	ParentSpanID      string           `json:"parentSpanId"`
+	Name              string           `json:"name"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string           `json:"endTimeUnixNano"`
+	Attributes        []tempoAttribute `json:"attributes"`
+	Status            struct {
+		Code    interface{} `json:"code"` // STATUS_CODE_ERROR ou 2, conforme a versão
+		Message string      `json:"message"`
+	} `json:"status"`
+}
+
+type tempoAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string  `json:"stringValue"`
+		IntValue    *string  `json:"intValue"`
+		BoolValue   *bool    `json:"boolValue"`
+		DoubleValue *float64 `json:"doubleValue"`
+	} `json:"value"`
+}
+
+// String retorna o valor do atributo como texto
+func (a tempoAttribute) String() string {
+	switch {
+	case a.Value.StringValue != nil:
+		return *a.Value.StringValue
+	case a.Value.IntValue != nil:
+		return *a.Value.IntValue
+	case a.Value.BoolValue != nil:
+		return strconv.FormatBool(*a.Value.BoolValue)
+	case a.Value.DoubleValue != nil:
+		return strconv.FormatFloat(*a.Value.DoubleValue, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// GetTrace obtém todos os spans de um trace
+func (b *tempoBackend) GetTrace(ctx context.Context, traceID string) (*Detail, error) {
+	var resp tempoTraceResponse
+	if err := getJSON(ctx, b.client, b.baseURL+"/api/traces/"+url.PathEscape(traceID), &resp); err != nil {
+		return nil, err
+	}
+
+	detail := &Detail{TraceID: traceID}
+	for _, batch := range resp.Batches {
+		var service string
+		for _, attr := range batch.Resource.Attributes {
+			if attr.Key == "service.name" {
+				service = attr.String()
+			}
+		}
+
+		for _, scope := range append(batch.ScopeSpans, batch.InstrumentationLibrarySpans...) {
+			for _, span := range scope.Spans {
+				startNano, _ := strconv.ParseInt(span.StartTimeUnixNano, 10, 64)
+				endNano, _ := strconv.ParseInt(span.EndTimeUnixNano, 10, 64)
+				s := Span{
+					SpanID:        tempoSpanID(span.SpanID),
+					ParentID:      tempoSpanID(span.ParentSpanID),
+					Service:       service,
+					Name:          span.Name,
+					Start:         time.Unix(0, startNano),
+					Duration:      time.Duration(endNano - startNano),
+					Attributes:    make(map[string]string, len(span.Attributes)),
+					StatusMessage: span.Status.Message,
+				}
+				switch code := span.Status.Code.(type) {
+				case string:
+					s.Error = code == "STATUS_CODE_ERROR"
+				case float64:
+					s.Error = code == 2
+				}
+				for _, attr := range span.Attributes {
+					s.Attributes[attr.Key] = attr.String()
+				}
+				detail.Spans = append(detail.Spans, s)
+			}
+		}
+	}
+
+	if len(detail.Spans) == 0 {
+		return nil, fmt.Errorf("trace %s não encontrado", traceID)
+	}
+	return detail, nil
+}
+
+// tempoSpanID converte o ID de um span do Tempo, codificado em base64 no OTLP JSON, para hexadecimal
+func tempoSpanID(id string) string {
+	if id == "" {
+		return ""
+	}
+	if _, err := hex.DecodeString(id); err == nil && len(id) == 16 {
+		return id
+	}
+	raw, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return id
+	}
+	return hex.EncodeToString(raw)
+}
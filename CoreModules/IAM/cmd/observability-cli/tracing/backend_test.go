@@ -0,0 +1,235 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// novoBackendTeste inicia um servidor que responde com o corpo indicado e regista o pedido recebido
+func novoBackendTeste(t *testing.T, kind string, status int, body string) (Backend, *http.Request) {
+	t.Helper()
+	recebido := &http.Request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*recebido = *r.Clone(context.Background())
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	backend, err := NewBackend(kind, server.URL+"/")
+	require.NoError(t, err)
+	return backend, recebido
+}
+
+func TestNewBackend(t *testing.T) {
+	backend, err := NewBackend("Jaeger", "http://jaeger:16686/")
+	require.NoError(t, err)
+	assert.Equal(t, "http://jaeger:16686", backend.(*jaegerBackend).baseURL)
+
+	backend, err = NewBackend(BackendTempo, "http://tempo:3200")
+	require.NoError(t, err)
+	assert.IsType(t, &tempoBackend{}, backend)
+
+	_, err = NewBackend(BackendJaeger, "")
+	assert.EqualError(t, err, "URL do backend de tracing não configurada (--trace-backend-url)")
+
+	_, err = NewBackend("zipkin", "http://zipkin:9411")
+	assert.EqualError(t, err, "backend de tracing não suportado: zipkin (use jaeger ou tempo)")
+}
+
+const traceJaeger = `{"data": [{
+	"traceID": "a3ce929d0e0e4736",
+	"processes": {"p1": {"serviceName": "bureau-orquestrador"}, "p2": {"serviceName": "provedor-gateway"}},
+	"spans": [
+		{"spanID": "s1", "operationName": "consulta", "references": [], "startTime": 1775120400000000, "duration": 200000, "processID": "p1",
+		 "tags": [{"key": "consulta.id", "value": "c-42"}, {"key": "market", "value": "Angola"}]},
+		{"spanID": "s2", "operationName": "provedor.chamada", "processID": "p2", "startTime": 1775120400050000, "duration": 100000,
+		 "references": [{"refType": "FOLLOWS_FROM", "spanID": "s9"}, {"refType": "CHILD_OF", "spanID": "s1"}],
+		 "tags": [{"key": "provider.id", "value": "bureau-ao"}, {"key": "error", "value": true}, {"key": "otel.status_description", "value": "timeout"}, {"key": "http.status_code", "value": 504}]},
+		{"spanID": "s3", "operationName": "provedor.chamada", "processID": "p2", "startTime": 1775120400060000, "duration": 20000,
+		 "references": [{"refType": "CHILD_OF", "spanID": "s1"}],
+		 "tags": [{"key": "provider.id", "value": "bureau-ao"}, {"key": "otel.status_code", "value": "ERROR"}]}
+	]
+}]}`
+
+func TestJaegerGetTrace(t *testing.T) {
+	backend, pedido := novoBackendTeste(t, BackendJaeger, http.StatusOK, traceJaeger)
+
+	detail, err := backend.GetTrace(context.Background(), "a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/traces/a3ce929d0e0e4736", pedido.URL.Path)
+	assert.Equal(t, "application/json", pedido.Header.Get("Accept"))
+	assert.Equal(t, "a3ce929d0e0e4736", detail.TraceID)
+	require.Len(t, detail.Spans, 3)
+
+	raiz := detail.Spans[0]
+	assert.Empty(t, raiz.ParentID)
+	assert.Equal(t, "bureau-orquestrador", raiz.Service)
+	assert.Equal(t, time.UnixMicro(1775120400000000), raiz.Start)
+	assert.Equal(t, 200*time.Millisecond, raiz.Duration)
+	assert.Equal(t, map[string]string{"consulta.id": "c-42", "market": "Angola"}, raiz.Attributes)
+	assert.False(t, raiz.Error)
+
+	provedor := detail.Spans[1]
+	assert.Equal(t, "s1", provedor.ParentID, "a referência CHILD_OF prevalece sobre FOLLOWS_FROM")
+	assert.Equal(t, "provedor-gateway", provedor.Service)
+	assert.True(t, provedor.Error)
+	assert.Equal(t, "timeout", provedor.StatusMessage)
+	assert.Equal(t, map[string]string{"provider.id": "bureau-ao", "http.status_code": "504"}, provedor.Attributes,
+		"as tags de estado não são exibidas como atributos")
+
+	assert.True(t, detail.Spans[2].Error, "otel.status_code=ERROR marca o span com erro")
+}
+
+func TestJaegerGetTraceErrors(t *testing.T) {
+	backend, _ := novoBackendTeste(t, BackendJaeger, http.StatusOK, `{"data": []}`)
+	_, err := backend.GetTrace(context.Background(), "a3ce929d0e0e4736")
+	assert.EqualError(t, err, "trace a3ce929d0e0e4736 não encontrado")
+
+	backend, _ = novoBackendTeste(t, BackendJaeger, http.StatusNotFound, "  trace not found\n")
+	_, err = backend.GetTrace(context.Background(), "a3ce929d0e0e4736")
+	assert.EqualError(t, err, "status 404: trace not found")
+
+	backend, _ = novoBackendTeste(t, BackendJaeger, http.StatusOK, "<html>")
+	_, err = backend.GetTrace(context.Background(), "a3ce929d0e0e4736")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "erro ao decodificar resposta")
+}
+
+func TestJaegerSearchTraces(t *testing.T) {
+	backend, pedido := novoBackendTeste(t, BackendJaeger, http.StatusOK, traceJaeger)
+	fim := time.Unix(1775120500, 0)
+
+	summaries, err := backend.SearchTraces(context.Background(), Query{
+		Service: "bureau-orquestrador",
+		Tags:    map[string]string{ConsultaIDAttribute: "c-42"},
+		Start:   fim.Add(-time.Hour),
+		End:     fim,
+		Limit:   20,
+	})
+	require.NoError(t, err)
+
+	params := pedido.URL.Query()
+	assert.Equal(t, "/api/traces", pedido.URL.Path)
+	assert.Equal(t, "bureau-orquestrador", params.Get("service"))
+	assert.JSONEq(t, `{"consulta.id": "c-42"}`, params.Get("tags"))
+	assert.Equal(t, "1775116900000000", params.Get("start"))
+	assert.Equal(t, "1775120500000000", params.Get("end"))
+	assert.Equal(t, "20", params.Get("limit"))
+
+	require.Len(t, summaries, 1)
+	summary := summaries[0]
+	assert.Equal(t, "consulta", summary.RootName)
+	assert.Equal(t, "bureau-orquestrador", summary.RootService)
+	assert.Equal(t, 3, summary.SpanCount)
+	assert.Equal(t, 2, summary.Errors)
+	assert.Equal(t, []string{"bureau-ao"}, summary.Providers, "provedores sem repetições")
+	assert.Equal(t, time.UnixMicro(1775120400000000), summary.Start)
+	assert.Equal(t, 200*time.Millisecond, summary.Duration)
+}
+
+// traceTempo tem spans em dois lotes, com IDs em base64 e os códigos de estado das duas versões do OTLP JSON
+const traceTempo = `{"batches": [
+	{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "mcp-iam-hooks"}}]},
+	 "scopeSpans": [{"spans": [
+		{"spanId": "AAAAAAAAAAE=", "name": "hook.mfa_validation", "startTimeUnixNano": "1775120400000000000", "endTimeUnixNano": "1775120400050000000",
+		 "attributes": [{"key": "market", "value": {"stringValue": "Brazil"}}, {"key": "retry", "value": {"intValue": "2"}},
+		                {"key": "dual", "value": {"boolValue": true}}, {"key": "ratio", "value": {"doubleValue": 0.5}}, {"key": "vazio", "value": {}}],
+		 "status": {}}
+	 ]}]},
+	{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "otp-service"}}]},
+	 "instrumentationLibrarySpans": [{"spans": [
+		{"spanId": "00000000000000aa", "parentSpanId": "AAAAAAAAAAE=", "name": "otp.verify", "startTimeUnixNano": "1775120400010000000", "endTimeUnixNano": "1775120400020000000",
+		 "status": {"code": "STATUS_CODE_ERROR", "message": "OTP expirado"}},
+		{"spanId": "00000000000000bb", "parentSpanId": "AAAAAAAAAAE=", "name": "otp.send", "startTimeUnixNano": "1775120400020000000", "endTimeUnixNano": "1775120400030000000",
+		 "status": {"code": 2}}
+	 ]}]}
+]}`
+
+func TestTempoGetTrace(t *testing.T) {
+	backend, pedido := novoBackendTeste(t, BackendTempo, http.StatusOK, traceTempo)
+
+	detail, err := backend.GetTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/traces/4bf92f3577b34da6a3ce929d0e0e4736", pedido.URL.Path)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", detail.TraceID)
+	require.Len(t, detail.Spans, 3)
+
+	hook := detail.Spans[0]
+	assert.Equal(t, "0000000000000001", hook.SpanID, "IDs em base64 convertidos para hexadecimal")
+	assert.Empty(t, hook.ParentID)
+	assert.Equal(t, "mcp-iam-hooks", hook.Service)
+	assert.Equal(t, 50*time.Millisecond, hook.Duration)
+	assert.Equal(t, map[string]string{"market": "Brazil", "retry": "2", "dual": "true", "ratio": "0.5", "vazio": ""}, hook.Attributes)
+	assert.False(t, hook.Error)
+
+	otp := detail.Spans[1]
+	assert.Equal(t, "00000000000000aa", otp.SpanID)
+	assert.Equal(t, "0000000000000001", otp.ParentID)
+	assert.Equal(t, "otp-service", otp.Service)
+	assert.True(t, otp.Error)
+	assert.Equal(t, "OTP expirado", otp.StatusMessage)
+	assert.True(t, detail.Spans[2].Error, "o código numérico 2 corresponde a erro")
+
+	// Os spans dos dois lotes formam uma única árvore
+	roots := BuildTree(detail.Spans)
+	require.Len(t, roots, 1)
+	assert.Len(t, roots[0].Children, 2)
+}
+
+func TestTempoGetTraceNotFound(t *testing.T) {
+	backend, _ := novoBackendTeste(t, BackendTempo, http.StatusOK, `{"batches": []}`)
+	_, err := backend.GetTrace(context.Background(), "a3ce929d0e0e4736")
+	assert.EqualError(t, err, "trace a3ce929d0e0e4736 não encontrado")
+}
+
+func TestTempoSearchTraces(t *testing.T) {
+	resposta, err := json.Marshal(map[string]interface{}{"traces": []map[string]interface{}{{
+		"traceID": "4bf92f3577b34da6", "rootServiceName": "bureau-orquestrador", "rootTraceName": "consulta",
+		"startTimeUnixNano": "1775120400000000000", "durationMs": 250,
+	}}})
+	require.NoError(t, err)
+	backend, pedido := novoBackendTeste(t, BackendTempo, http.StatusOK, string(resposta))
+	fim := time.Unix(1775120500, 0)
+
+	summaries, err := backend.SearchTraces(context.Background(), Query{
+		Service: "bureau-orquestrador",
+		Tags:    map[string]string{ConsultaIDAttribute: "c-42"},
+		Start:   fim.Add(-time.Hour),
+		End:     fim,
+	})
+	require.NoError(t, err)
+
+	params := pedido.URL.Query()
+	assert.Equal(t, "/api/search", pedido.URL.Path)
+	assert.Equal(t, `consulta.id="c-42" service.name="bureau-orquestrador"`, params.Get("tags"), "atributos em logfmt ordenados")
+	assert.Equal(t, "1775116900", params.Get("start"))
+	assert.Equal(t, "1775120500", params.Get("end"))
+	assert.False(t, strings.Contains(pedido.URL.RawQuery, "limit"), "sem limite o parâmetro é omitido")
+
+	require.Len(t, summaries, 1)
+	assert.Equal(t, Summary{
+		TraceID:     "4bf92f3577b34da6",
+		RootService: "bureau-orquestrador",
+		RootName:    "consulta",
+		Start:       time.Unix(0, 1775120400000000000),
+		Duration:    250 * time.Millisecond,
+	}, summaries[0])
+}
+
+func TestTempoSpanID(t *testing.T) {
+	assert.Equal(t, "", tempoSpanID(""))
+	assert.Equal(t, "00000000000000aa", tempoSpanID("00000000000000aa"))
+	assert.Equal(t, "0000000000000001", tempoSpanID("AAAAAAAAAAE="))
+	assert.Equal(t, "não-base64!", tempoSpanID("não-base64!"), "IDs que não são base64 mantêm-se")
+}
@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Largura da barra que posiciona cada span na linha temporal do trace
+const barWidth = 24
+
+// Atributos de mercado e compliance exibidos junto de cada span
+var highlightAttributes = map[string]bool{
+	"market":            true,
+	"tenant_type":       true,
+	"tenant.id":         true,
+	"tenant_id":         true,
+	"hook_type":         true,
+	"mfa_level":         true,
+	ConsultaIDAttribute: true,
+	ProviderAttribute:   true,
+}
+
+// Node é um span com os seus filhos na árvore do trace
+type Node struct {
+	Span     Span
+	Children []*Node
+}
+
+// Line é uma linha da árvore do trace; as linhas de spans com erro são destacadas
+type Line struct {
+	Text  string
+	Error bool
+}
+
+// ParseTraceID normaliza o ID de um trace, que deve ter 16 ou 32 caracteres hexadecimais
+func ParseTraceID(raw string) (string, error) {
+	traceID := strings.ToLower(strings.TrimSpace(raw))
+	if _, err := hex.DecodeString(traceID); err != nil || (len(traceID) != 16 && len(traceID) != 32) {
+		return "", fmt.Errorf("ID de trace inválido: %s (esperados 16 ou 32 caracteres hexadecimais)", raw)
+	}
+	return traceID, nil
+}
+
+// BuildTree organiza os spans por parentesco, ordenados pelo início
+// Spans cujo pai não consta do trace são tratados como raízes
+func BuildTree(spans []Span) []*Node {
+	nodes := make(map[string]*Node, len(spans))
+	for _, span := range spans {
+		nodes[span.SpanID] = &Node{Span: span}
+	}
+
+	var roots []*Node
+	for _, span := range spans {
+		node := nodes[span.SpanID]
+		if parent, ok := nodes[span.ParentID]; ok && span.ParentID != span.SpanID {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	var sortNodes func([]*Node)
+	sortNodes = func(list []*Node) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Span.Start.Before(list[j].Span.Start) })
+		for _, node := range list {
+			sortNodes(node.Children)
+		}
+	}
+	sortNodes(roots)
+	return roots
+}
+
+// Render retorna o cabeçalho do trace e a árvore de spans, uma linha por span
+// Sem allAttributes, apenas os atributos de mercado e compliance são exibidos
+func Render(detail *Detail, allAttributes bool) (Line, []Line) {
+	var start, end time.Time
+	errors := 0
+	for _, span := range detail.Spans {
+		if start.IsZero() || span.Start.Before(start) {
+			start = span.Start
+		}
+		if spanEnd := span.Start.Add(span.Duration); spanEnd.After(end) {
+			end = spanEnd
+		}
+		if span.Error {
+			errors++
+		}
+	}
+	total := end.Sub(start)
+
+	header := Line{Text: fmt.Sprintf("Trace %s  início=%s  duração=%s  spans=%d",
+		detail.TraceID, start.Format(time.RFC3339), total.Round(time.Microsecond), len(detail.Spans))}
+	if errors > 0 {
+		header.Text += fmt.Sprintf("  erros=%d", errors)
+		header.Error = true
+	}
+
+	var lines []Line
+	var walk func(node *Node, prefix string, last, root bool)
+	walk = func(node *Node, prefix string, last, root bool) {
+		branch, childPrefix := "", prefix
+		if !root {
+			branch, childPrefix = "├─ ", prefix+"│  "
+			if last {
+				branch, childPrefix = "└─ ", prefix+"   "
+			}
+		}
+
+		span := node.Span
+		line := Line{
+			Text: fmt.Sprintf("%s %s%s%s [%s] %s",
+				bar(span, start, total), prefix, branch, span.Name, span.Service, span.Duration.Round(time.Microsecond)),
+			Error: span.Error,
+		}
+		if attrs := formatAttributes(span.Attributes, allAttributes); attrs != "" {
+			line.Text += "  " + attrs
+		}
+		if span.Error && span.StatusMessage != "" {
+			line.Text += "  erro: " + span.StatusMessage
+		}
+		lines = append(lines, line)
+
+		for i, child := range node.Children {
+			walk(child, childPrefix, i == len(node.Children)-1, false)
+		}
+	}
+	for _, root := range BuildTree(detail.Spans) {
+		walk(root, "", true, true)
+	}
+	return header, lines
+}
+
+// bar desenha a posição e a duração do span na linha temporal do trace
+func bar(span Span, start time.Time, total time.Duration) string {
+	line := []rune(strings.Repeat("·", barWidth))
+	if total <= 0 {
+		return "|" + strings.Repeat("█", barWidth) + "|"
+	}
+
+	from := int(float64(span.Start.Sub(start)) / float64(total) * barWidth)
+	to := int(float64(span.Start.Add(span.Duration).Sub(start)) / float64(total) * barWidth)
+	if from >= barWidth {
+		from = barWidth - 1
+	}
+	if to <= from {
+		to = from + 1
+	}
+	if to > barWidth {
+		to = barWidth
+	}
+	for i := from; i < to; i++ {
+		line[i] = '█'
+	}
+	return "|" + string(line) + "|"
+}
+
+// formatAttributes formata os atributos do span em key=value, ordenados pela chave
+// Sem allAttributes, apenas os atributos de mercado e compliance são exibidos
+func formatAttributes(attributes map[string]string, allAttributes bool) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		if allAttributes || highlightAttributes[key] || strings.Contains(key, "compliance") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+attributes[key])
+	}
+	return strings.Join(parts, " ")
+}
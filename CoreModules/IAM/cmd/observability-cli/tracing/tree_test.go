@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var inicioTrace = time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC)
+
+// spanHook cria um span que começa offset depois do início do trace
+func spanHook(id, parent, name string, offset, duration time.Duration) Span {
+	return Span{
+		SpanID:     id,
+		ParentID:   parent,
+		Service:    "mcp-iam-hooks",
+		Name:       name,
+		Start:      inicioTrace.Add(offset),
+		Duration:   duration,
+		Attributes: map[string]string{},
+	}
+}
+
+func TestParseTraceID(t *testing.T) {
+	traceID, err := ParseTraceID("  4BF92F3577B34DA6A3CE929D0E0E4736 ")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+
+	traceID, err = ParseTraceID("a3ce929d0e0e4736")
+	require.NoError(t, err)
+	assert.Equal(t, "a3ce929d0e0e4736", traceID, "IDs de 64 bits do Jaeger são aceites")
+
+	for _, invalido := range []string{"", "xyz", "a3ce929d0e0e473", "4bf92f3577b34da6a3ce929d0e0e4736ff"} {
+		_, err := ParseTraceID(invalido)
+		require.Error(t, err, invalido)
+		assert.Contains(t, err.Error(), "esperados 16 ou 32 caracteres hexadecimais")
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	spans := []Span{
+		spanHook("c2", "root", "mfa.validate", 30*time.Millisecond, 10*time.Millisecond),
+		spanHook("root", "", "hook.privilege_elevation", 0, 100*time.Millisecond),
+		spanHook("c1", "root", "scope.validate", 10*time.Millisecond, 10*time.Millisecond),
+		spanHook("g1", "c1", "policy.evaluate", 12*time.Millisecond, 5*time.Millisecond),
+		// O pai não consta do trace: o span é tratado como raiz
+		spanHook("orfao", "perdido", "audit.write", 5*time.Millisecond, time.Millisecond),
+		// Um span que se refere a si próprio não entra em ciclo
+		spanHook("self", "self", "loop", 50*time.Millisecond, time.Millisecond),
+	}
+
+	roots := BuildTree(spans)
+
+	var nomes []string
+	for _, root := range roots {
+		nomes = append(nomes, root.Span.Name)
+	}
+	assert.Equal(t, []string{"hook.privilege_elevation", "audit.write", "loop"}, nomes, "raízes ordenadas pelo início")
+
+	root := roots[0]
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, "scope.validate", root.Children[0].Span.Name)
+	assert.Equal(t, "mfa.validate", root.Children[1].Span.Name)
+	require.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, "policy.evaluate", root.Children[0].Children[0].Span.Name)
+	assert.Empty(t, roots[2].Children)
+}
+
+func TestRender(t *testing.T) {
+	root := spanHook("root", "", "hook.privilege_elevation", 0, 100*time.Millisecond)
+	root.Attributes = map[string]string{"market": "Angola", "http.method": "POST", "compliance_framework": "BNA"}
+	filho := spanHook("c1", "root", "scope.validate", 50*time.Millisecond, 50*time.Millisecond)
+	erro := spanHook("c2", "root", "mfa.validate", 0, 25*time.Millisecond)
+	erro.Error = true
+	erro.StatusMessage = "nível MFA insuficiente"
+	neto := spanHook("g1", "c2", "otp.verify", 0, 10*time.Millisecond)
+
+	detail := &Detail{TraceID: "4bf92f3577b34da6", Spans: []Span{filho, root, erro, neto}}
+	header, lines := Render(detail, false)
+
+	assert.Equal(t, Line{Text: "Trace 4bf92f3577b34da6  início=2026-04-02T09:00:00Z  duração=100ms  spans=4  erros=1", Error: true}, header)
+	require.Len(t, lines, 4)
+	assert.Equal(t, Line{Text: "|████████████████████████| hook.privilege_elevation [mcp-iam-hooks] 100ms  compliance_framework=BNA market=Angola"}, lines[0],
+		"sem --all-attributes apenas os atributos de mercado e compliance são exibidos")
+	assert.Equal(t, Line{Text: "|██████··················| ├─ mfa.validate [mcp-iam-hooks] 25ms  erro: nível MFA insuficiente", Error: true}, lines[1])
+	assert.Equal(t, Line{Text: "|██······················| │  └─ otp.verify [mcp-iam-hooks] 10ms"}, lines[2])
+	assert.Equal(t, Line{Text: "|············████████████| └─ scope.validate [mcp-iam-hooks] 50ms"}, lines[3])
+
+	_, lines = Render(detail, true)
+	assert.Contains(t, lines[0].Text, "compliance_framework=BNA http.method=POST market=Angola")
+
+	header, _ = Render(&Detail{TraceID: "a3ce929d0e0e4736", Spans: []Span{filho}}, false)
+	assert.False(t, header.Error)
+	assert.NotContains(t, header.Text, "erros=")
+}
+
+func TestBar(t *testing.T) {
+	instantaneo := spanHook("s", "", "s", 0, 0)
+	assert.Equal(t, "|████████████████████████|", bar(instantaneo, inicioTrace, 0), "um trace sem duração ocupa toda a barra")
+
+	// Spans muito curtos ocupam pelo menos uma posição, mesmo no fim do trace
+	curto := spanHook("s", "", "s", 100*time.Millisecond, time.Nanosecond)
+	assert.Equal(t, "|·······················█|", bar(curto, inicioTrace, 100*time.Millisecond))
+}
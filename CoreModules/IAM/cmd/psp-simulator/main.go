@@ -0,0 +1,73 @@
+// Package main fornece o simulador de PSP do Payment Gateway.
+//
+// O simulador serve a API de autorizações de um PSP com respostas determinísticas por
+// cartão e valor mágicos (recusas, desafios 3DS, tempos esgotados, erros e autorizações
+// pendentes concluídas por webhook), para testes de integração e ambientes de homologação.
+// Os cartões e valores estão documentados em paymentgateway.PSPSimulator.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run serve o simulador até receber SIGINT ou SIGTERM e retorna o código de saída do processo
+func run(args []string) int {
+	flags := flag.NewFlagSet("psp-simulator", flag.ContinueOnError)
+	addr := flags.String("addr", ":8099", "Endereço de escuta do simulador")
+	apiKey := flags.String("api-key", os.Getenv("PSP_SIMULATOR_API_KEY"), "Chave exigida no cabeçalho Authorization (vazio desativa)")
+	webhookSecret := flags.String("webhook-secret", os.Getenv("PSP_SIMULATOR_WEBHOOK_SECRET"), "Segredo HMAC dos webhooks")
+	webhookURL := flags.String("webhook-url", "", "Destino dos webhooks quando o pedido não indica notification_url")
+	timeoutDelay := flags.Duration("timeout-delay", paymentgateway.DefaultPSPSimulatorTimeoutDelay, "Espera dos valores terminados em ,91")
+	webhookDelay := flags.Duration("webhook-delay", time.Second, "Espera antes do webhook das autorizações pendentes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	simulator, err := paymentgateway.NewPSPSimulator(paymentgateway.PSPSimulatorConfig{
+		APIKey:        *apiKey,
+		WebhookSecret: *webhookSecret,
+		WebhookURL:    *webhookURL,
+		TimeoutDelay:  *timeoutDelay,
+		WebhookDelay:  *webhookDelay,
+	})
+	if err != nil {
+		log.Printf("Falha ao inicializar o simulador: %v", err)
+		return 1
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           simulator,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Encerramento gracioso: aguardar os pedidos em curso antes de sair
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	log.Printf("Simulador de PSP a escutar em %s", *addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Falha no simulador de PSP: %v", err)
+		return 1
+	}
+	simulator.Wait()
+	return 0
+}
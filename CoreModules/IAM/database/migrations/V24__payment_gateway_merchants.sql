@@ -0,0 +1,90 @@
+-- ==========================================================================
+-- Nome: V24__payment_gateway_merchants.sql
+-- Descrição: Migração para o subsistema de comerciantes do Payment Gateway
+--            (registo, KYC, limites e métricas de risco por comerciante)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE COMERCIANTES
+-- ==========================================================================
+
+-- Tabela de comerciantes
+CREATE TABLE IF NOT EXISTS payment_gateway.merchants (
+    merchant_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    legal_name VARCHAR(255) NOT NULL,
+    trade_name VARCHAR(255) NOT NULL DEFAULT '',
+    tax_id VARCHAR(100) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    merchant_category VARCHAR(50) NOT NULL DEFAULT '',
+    contact_email VARCHAR(255) NOT NULL DEFAULT '',
+    contact_phone VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(30) NOT NULL DEFAULT 'pending',
+    status_reason TEXT,
+    supported_payment_types TEXT[] NOT NULL DEFAULT '{}',
+    required_kyc_documents TEXT[] NOT NULL DEFAULT '{}',
+    limits JSONB NOT NULL DEFAULT '{}',
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    activated_at TIMESTAMP WITH TIME ZONE,
+    suspended_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT uk_merchants_tenant_tax_id UNIQUE (tenant_id, tax_id),
+    CONSTRAINT ck_merchants_status CHECK (status IN ('pending', 'active', 'suspended'))
+);
+
+-- Tabela de documentos KYC dos comerciantes
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_kyc_documents (
+    document_id VARCHAR(255) PRIMARY KEY,
+    merchant_id VARCHAR(255) NOT NULL,
+    document_type VARCHAR(100) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'pending',
+    reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+    review_notes TEXT NOT NULL DEFAULT '',
+    uploaded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_kyc_documents_merchant FOREIGN KEY (merchant_id) REFERENCES payment_gateway.merchants(merchant_id) ON DELETE CASCADE,
+    CONSTRAINT ck_kyc_documents_status CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+-- Tabela de métricas de risco e compliance por comerciante
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_risk_metrics (
+    merchant_id VARCHAR(255) PRIMARY KEY,
+    total_transactions BIGINT NOT NULL DEFAULT 0,
+    approved_count BIGINT NOT NULL DEFAULT 0,
+    denied_count BIGINT NOT NULL DEFAULT 0,
+    challenged_count BIGINT NOT NULL DEFAULT 0,
+    chargeback_count BIGINT NOT NULL DEFAULT 0,
+    anomaly_count BIGINT NOT NULL DEFAULT 0,
+    total_volume NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    average_trust_score NUMERIC(6, 2) NOT NULL DEFAULT 0,
+    compliance_flags BIGINT NOT NULL DEFAULT 0,
+    last_transaction_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_risk_metrics_merchant FOREIGN KEY (merchant_id) REFERENCES payment_gateway.merchants(merchant_id) ON DELETE CASCADE
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_merchants_tenant_status ON payment_gateway.merchants(tenant_id, status);
+CREATE INDEX IF NOT EXISTS idx_merchants_region ON payment_gateway.merchants(region_code);
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_merchant ON payment_gateway.merchant_kyc_documents(merchant_id, document_type);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.merchants IS 'Comerciantes registados no Payment Gateway com limites e tipos de pagamento suportados';
+COMMENT ON TABLE payment_gateway.merchant_kyc_documents IS 'Documentos KYC submetidos pelos comerciantes durante o registo';
+COMMENT ON TABLE payment_gateway.merchant_risk_metrics IS 'Métricas agregadas de risco e compliance por comerciante';
@@ -0,0 +1,90 @@
+-- ==========================================================================
+-- Nome: V25__payment_gateway_daily_summaries.sql
+-- Descrição: Migração para o fecho diário do Payment Gateway
+--            (livro diário de transações, resumos por mercado e comerciante e exportações)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DO FECHO DIÁRIO
+-- ==========================================================================
+
+-- Livro diário de pagamentos processados, reembolsos e disputas
+CREATE TABLE IF NOT EXISTS payment_gateway.transaction_ledger (
+    entry_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+    transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    entry_type VARCHAR(20) NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT '',
+    amount NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_transaction_ledger_entry_type CHECK (entry_type IN ('payment', 'refund', 'dispute'))
+);
+
+-- Resumos diários por tenant, mercado, comerciante e moeda
+CREATE TABLE IF NOT EXISTS payment_gateway.daily_summaries (
+    tenant_id VARCHAR(255) NOT NULL,
+    summary_date DATE NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    transaction_count BIGINT NOT NULL DEFAULT 0,
+    approved_count BIGINT NOT NULL DEFAULT 0,
+    denied_count BIGINT NOT NULL DEFAULT 0,
+    challenged_count BIGINT NOT NULL DEFAULT 0,
+    error_count BIGINT NOT NULL DEFAULT 0,
+    approval_rate NUMERIC(6, 4) NOT NULL DEFAULT 0,
+    approved_volume NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    refund_count BIGINT NOT NULL DEFAULT 0,
+    refund_volume NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    dispute_count BIGINT NOT NULL DEFAULT 0,
+    dispute_volume NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    net_volume NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (summary_date, tenant_id, region_code, merchant_id, currency)
+);
+
+-- Dias fechados, incluindo os fechados sem lançamentos
+CREATE TABLE IF NOT EXISTS payment_gateway.daily_summary_runs (
+    summary_date DATE PRIMARY KEY,
+    summary_count INTEGER NOT NULL DEFAULT 0,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Ficheiros de resumos exportados para o armazenamento de objetos
+CREATE TABLE IF NOT EXISTS payment_gateway.daily_summary_exports (
+    export_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    summary_date DATE NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    object_key TEXT NOT NULL,
+    summary_count INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_daily_summary_exports_format CHECK (format IN ('csv', 'parquet'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_transaction_ledger_occurred_at ON payment_gateway.transaction_ledger(occurred_at);
+CREATE INDEX IF NOT EXISTS idx_daily_summaries_tenant_date ON payment_gateway.daily_summaries(tenant_id, summary_date);
+CREATE INDEX IF NOT EXISTS idx_daily_summary_exports_tenant_date ON payment_gateway.daily_summary_exports(tenant_id, summary_date);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.transaction_ledger IS 'Livro diário de pagamentos, reembolsos e disputas usado no fecho diário';
+COMMENT ON TABLE payment_gateway.daily_summaries IS 'Resumos financeiros diários por tenant, mercado, comerciante e moeda';
+COMMENT ON TABLE payment_gateway.daily_summary_runs IS 'Dias já fechados pelo job de fecho diário';
+COMMENT ON TABLE payment_gateway.daily_summary_exports IS 'Ficheiros CSV/Parquet de resumos diários exportados para o armazenamento de objetos';
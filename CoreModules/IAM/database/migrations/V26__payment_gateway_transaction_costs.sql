@@ -0,0 +1,48 @@
+-- ==========================================================================
+-- Nome: V26__payment_gateway_transaction_costs.sql
+-- Descrição: Migração para a contabilidade de custos do Payment Gateway
+--            (custo de processamento atribuído a cada transação)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DA CONTABILIDADE DE CUSTOS
+-- ==========================================================================
+
+-- Custo de processamento de cada transação segundo a tabela de custos do mercado
+CREATE TABLE IF NOT EXISTS payment_gateway.transaction_costs (
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(30) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',
+    rate_card_id VARCHAR(255) NOT NULL DEFAULT '',
+    psp_fee NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    screening_fee NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    bureau_fee NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    total_cost NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    components JSONB NOT NULL DEFAULT '[]',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, transaction_id)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_transaction_costs_tenant_occurred_at ON payment_gateway.transaction_costs(tenant_id, occurred_at);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.transaction_costs IS 'Custo de processamento (PSP, verificação e bureau) atribuído a cada transação';
+COMMENT ON COLUMN payment_gateway.transaction_costs.rate_card_id IS 'Tabela de custos aplicada; vazio quando nenhuma tabela cobre o mercado e a moeda';
+COMMENT ON COLUMN payment_gateway.transaction_costs.components IS 'Componentes do custo com a tarifa aplicada a cada um';
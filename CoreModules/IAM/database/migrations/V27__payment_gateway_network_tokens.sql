@@ -0,0 +1,71 @@
+-- ==========================================================================
+-- Nome: V27__payment_gateway_network_tokens.sql
+-- Descrição: Migração para a tokenização de rede do Payment Gateway
+--            (tokens Visa/Mastercard associados aos cartões do cofre e
+--            eventos do ciclo de vida enviados pelos emissores)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DA TOKENIZAÇÃO DE REDE
+-- ==========================================================================
+
+-- Cartões do cofre com o respetivo token de rede (sem PAN, token nem criptogramas)
+CREATE TABLE IF NOT EXISTS payment_gateway.card_tokens (
+    card_token_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    vault_token_id VARCHAR(255) NOT NULL,
+    network VARCHAR(30) NOT NULL DEFAULT '',
+    pan_last4 CHAR(4) NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    status_reason TEXT NOT NULL DEFAULT '',
+    token_reference_id VARCHAR(255) NOT NULL DEFAULT '',
+    token_last4 VARCHAR(4) NOT NULL DEFAULT '',
+    token_expiry_month INTEGER NOT NULL DEFAULT 0,
+    token_expiry_year INTEGER NOT NULL DEFAULT 0,
+    payment_account_reference VARCHAR(64) NOT NULL DEFAULT '',
+    provisioning_attempts INTEGER NOT NULL DEFAULT 0,
+    provisioned_at TIMESTAMP WITH TIME ZONE,
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_card_tokens_tenant_vault_token UNIQUE (tenant_id, vault_token_id),
+    CONSTRAINT ck_card_tokens_status CHECK (status IN ('pending', 'active', 'suspended', 'deleted', 'ineligible'))
+);
+
+-- Eventos do ciclo de vida recebidos das redes (idempotência e auditoria)
+CREATE TABLE IF NOT EXISTS payment_gateway.network_token_events (
+    network VARCHAR(30) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    token_reference_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    applied BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (network, event_id),
+    CONSTRAINT ck_network_token_events_type CHECK (event_type IN ('suspend', 'resume', 'delete', 'update'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_card_tokens_tenant_user ON payment_gateway.card_tokens(tenant_id, user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_card_tokens_network_reference ON payment_gateway.card_tokens(network, token_reference_id) WHERE token_reference_id <> '';
+CREATE INDEX IF NOT EXISTS idx_network_token_events_reference ON payment_gateway.network_token_events(network, token_reference_id, occurred_at);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.card_tokens IS 'Tokens de rede (VTS/MDES) dos cartões guardados no cofre';
+COMMENT ON COLUMN payment_gateway.card_tokens.vault_token_id IS 'Token do cofre (FPAN), usado quando o token de rede não está disponível';
+COMMENT ON COLUMN payment_gateway.card_tokens.token_reference_id IS 'Referência do token na rede; o token e o criptograma são pedidos em cada autorização';
+COMMENT ON TABLE payment_gateway.network_token_events IS 'Suspensões, retomas, eliminações e atualizações dos tokens decididas pelos emissores';
+COMMENT ON COLUMN payment_gateway.network_token_events.applied IS 'Falso quando o evento é anterior ao último evento aplicado ou o token não existe';
@@ -0,0 +1,51 @@
+-- ==========================================================================
+-- Nome: V28__payment_gateway_webhook_signing_keys.sql
+-- Descrição: Migração para as chaves de assinatura dos webhooks do Payment
+--            Gateway (chaves HMAC/Ed25519 por comerciante, rotação com
+--            janela de sobreposição e revogação)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DAS CHAVES DE ASSINATURA DOS WEBHOOKS
+-- ==========================================================================
+
+-- Chaves de assinatura por comerciante (material secreto cifrado com a chave mestra)
+CREATE TABLE IF NOT EXISTS payment_gateway.webhook_signing_keys (
+    key_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    algorithm VARCHAR(30) NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    secret_ciphertext BYTEA NOT NULL,
+    public_key TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    retires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_reason TEXT NOT NULL DEFAULT '',
+    CONSTRAINT ck_webhook_signing_keys_algorithm CHECK (algorithm IN ('hmac-sha256', 'ed25519')),
+    CONSTRAINT ck_webhook_signing_keys_status CHECK (status IN ('active', 'retiring', 'retired', 'revoked')),
+    CONSTRAINT ck_webhook_signing_keys_retiring CHECK (status <> 'retiring' OR retires_at IS NOT NULL),
+    CONSTRAINT ck_webhook_signing_keys_revoked CHECK (status <> 'revoked' OR revoked_at IS NOT NULL)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_webhook_signing_keys_active ON payment_gateway.webhook_signing_keys(tenant_id, merchant_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_webhook_signing_keys_merchant ON payment_gateway.webhook_signing_keys(tenant_id, merchant_id, created_at DESC);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.webhook_signing_keys IS 'Chaves de assinatura dos webhooks enviados aos comerciantes';
+COMMENT ON COLUMN payment_gateway.webhook_signing_keys.secret_ciphertext IS 'Segredo HMAC ou semente Ed25519 cifrados com AES-256-GCM, associados ao key_id';
+COMMENT ON COLUMN payment_gateway.webhook_signing_keys.public_key IS 'Chave pública Ed25519 entregue ao comerciante (whpk_<base64>)';
+COMMENT ON COLUMN payment_gateway.webhook_signing_keys.retires_at IS 'Fim da janela de sobreposição em que a chave substituída continua a assinar';
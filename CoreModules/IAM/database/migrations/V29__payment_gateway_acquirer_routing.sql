@@ -0,0 +1,86 @@
+-- ==========================================================================
+-- Nome: V29__payment_gateway_acquirer_routing.sql
+-- Descrição: Migração para o encaminhamento entre adquirentes do Payment
+--            Gateway (decisões de encaminhamento, desempenho diário por
+--            adquirente e resultados das experiências A/B)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DO ENCAMINHAMENTO ENTRE ADQUIRENTES
+-- ==========================================================================
+
+-- Decisão de encaminhamento de cada autorização, com os candidatos e as tentativas da cascata
+CREATE TABLE IF NOT EXISTS payment_gateway.acquirer_routing_decisions (
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    amount DECIMAL(19,4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    rule_id VARCHAR(255) NOT NULL DEFAULT '',
+    strategy VARCHAR(30) NOT NULL,
+    experiment_id VARCHAR(255) NOT NULL DEFAULT '',
+    variant VARCHAR(100) NOT NULL DEFAULT '',
+    candidates JSONB NOT NULL DEFAULT '[]',
+    attempts JSONB NOT NULL DEFAULT '[]',
+    acquirer_id VARCHAR(255) NOT NULL DEFAULT '',
+    outcome VARCHAR(30) NOT NULL,
+    decided_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_acquirer_routing_decisions_strategy CHECK (strategy IN ('least_cost', 'highest_approval', 'priority')),
+    CONSTRAINT ck_acquirer_routing_decisions_outcome CHECK (outcome IN ('approved', 'soft_decline', 'hard_decline', 'unavailable'))
+);
+
+-- Tentativas diárias por adquirente, mercado e método de pagamento
+CREATE TABLE IF NOT EXISTS payment_gateway.acquirer_daily_stats (
+    stats_date DATE NOT NULL,
+    acquirer_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    approvals BIGINT NOT NULL DEFAULT 0,
+    soft_declines BIGINT NOT NULL DEFAULT 0,
+    hard_declines BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    total_latency_ms BIGINT NOT NULL DEFAULT 0,
+    approved_volume DECIMAL(19,4) NOT NULL DEFAULT 0,
+    estimated_cost DECIMAL(19,6) NOT NULL DEFAULT 0,
+    PRIMARY KEY (stats_date, acquirer_id, region_code, payment_method)
+);
+
+-- Transações diárias por variante das experiências de encaminhamento
+CREATE TABLE IF NOT EXISTS payment_gateway.routing_experiment_daily_stats (
+    experiment_id VARCHAR(255) NOT NULL,
+    variant VARCHAR(100) NOT NULL,
+    stats_date DATE NOT NULL,
+    transactions BIGINT NOT NULL DEFAULT 0,
+    approvals BIGINT NOT NULL DEFAULT 0,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    approved_volume DECIMAL(19,4) NOT NULL DEFAULT 0,
+    estimated_cost DECIMAL(19,6) NOT NULL DEFAULT 0,
+    PRIMARY KEY (experiment_id, variant, stats_date)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_acquirer_routing_decisions_decided ON payment_gateway.acquirer_routing_decisions(decided_at);
+CREATE INDEX IF NOT EXISTS idx_acquirer_routing_decisions_experiment ON payment_gateway.acquirer_routing_decisions(experiment_id, variant) WHERE experiment_id <> '';
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.acquirer_routing_decisions IS 'Decisões de encaminhamento das autorizações entre adquirentes';
+COMMENT ON COLUMN payment_gateway.acquirer_routing_decisions.candidates IS 'Adquirentes elegíveis pela ordem da estratégia, com custo estimado e taxa de aprovação';
+COMMENT ON COLUMN payment_gateway.acquirer_routing_decisions.attempts IS 'Tentativas da cascata, com o resultado e a latência de cada adquirente';
+COMMENT ON COLUMN payment_gateway.acquirer_routing_decisions.acquirer_id IS 'Adquirente que aprovou ou recusou o pagamento; vazio quando nenhum respondeu';
+COMMENT ON TABLE payment_gateway.acquirer_daily_stats IS 'Desempenho diário dos adquirentes usado na estratégia highest_approval e nos relatórios';
+COMMENT ON COLUMN payment_gateway.acquirer_daily_stats.estimated_cost IS 'Tarifas estimadas das autorizações aprovadas';
+COMMENT ON TABLE payment_gateway.routing_experiment_daily_stats IS 'Resultados diários das variantes das experiências A/B de encaminhamento';
@@ -0,0 +1,57 @@
+-- ==========================================================================
+-- Nome: V30__payment_gateway_step_up.sql
+-- Descrição: Migração para o step-up de MFA do Payment Gateway (pagamentos
+--            de risco médio suspensos até a conclusão do desafio no serviço
+--            de identidade)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DO STEP-UP DE MFA
+-- ==========================================================================
+
+-- Pagamento suspenso por step-up, com o pedido, a resposta e o contexto necessários para o retomar
+CREATE TABLE IF NOT EXISTS payment_gateway.payment_step_ups (
+    step_up_id UUID NOT NULL UNIQUE,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    challenge_id VARCHAR(255) NOT NULL,
+    trust_score INTEGER NOT NULL,
+    risk_level VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    status_reason TEXT NOT NULL DEFAULT '',
+    mfa_level VARCHAR(50) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    request JSONB NOT NULL,
+    response JSONB,
+    device JSONB,
+    usage JSONB NOT NULL DEFAULT '{}',
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_payment_step_ups_status CHECK (status IN ('pending', 'verified', 'failed', 'expired'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_step_ups_challenge ON payment_gateway.payment_step_ups(challenge_id);
+CREATE INDEX IF NOT EXISTS idx_payment_step_ups_pending_expiry ON payment_gateway.payment_step_ups(expires_at) WHERE status = 'pending';
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.payment_step_ups IS 'Pagamentos de risco médio suspensos à espera da conclusão de um desafio de MFA no serviço de identidade';
+COMMENT ON COLUMN payment_gateway.payment_step_ups.challenge_id IS 'Identificador do desafio emitido pelo serviço de identidade';
+COMMENT ON COLUMN payment_gateway.payment_step_ups.status IS 'pending enquanto o pagamento está suspenso; verified retoma o pagamento; failed e expired abortam-no';
+COMMENT ON COLUMN payment_gateway.payment_step_ups.response IS 'Resposta desafiada enquanto pendente e resposta final do pagamento após a resolução';
+COMMENT ON COLUMN payment_gateway.payment_step_ups.usage IS 'Utilização do pagamento usada na atribuição de custos quando é finalizado';
@@ -0,0 +1,63 @@
+-- ==========================================================================
+-- Nome: V31__payment_gateway_device_profiles.sql
+-- Descrição: Migração para os perfis de dispositivo do Payment Gateway
+--            (impressões digitais conhecidas por usuário, histórico de
+--            utilização e confiança usada na pontuação de risco)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE PERFIS DE DISPOSITIVO
+-- ==========================================================================
+
+-- Dispositivos conhecidos de cada usuário; as colunas correspondem a dbDeviceProfile,
+-- lido com SELECT * pelo PostgresDeviceRepository
+CREATE TABLE IF NOT EXISTS payment_gateway.device_profiles (
+    device_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(512) NOT NULL,
+    label VARCHAR(255),
+    device_type VARCHAR(50) NOT NULL DEFAULT '',
+    device_model VARCHAR(255) NOT NULL DEFAULT '',
+    operating_system VARCHAR(100) NOT NULL DEFAULT '',
+    os_version VARCHAR(50) NOT NULL DEFAULT '',
+    browser VARCHAR(100) NOT NULL DEFAULT '',
+    browser_version VARCHAR(50) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    attributes JSONB NOT NULL DEFAULT '{}',
+    last_ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    last_country VARCHAR(2) NOT NULL DEFAULT '',
+    trusted BOOLEAN NOT NULL DEFAULT FALSE,
+    trust_revoked_at TIMESTAMP WITH TIME ZONE,
+    trust_revoked_by VARCHAR(255),
+    revocation_reason TEXT,
+    seen_count BIGINT NOT NULL DEFAULT 0,
+    successful_payments BIGINT NOT NULL DEFAULT 0,
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT ck_device_profiles_counters CHECK (seen_count >= 0 AND successful_payments >= 0),
+    -- Um dispositivo revogado não pode voltar a ser confiável sem o suporte limpar a revogação
+    CONSTRAINT ck_device_profiles_revoked_untrusted CHECK (trust_revoked_at IS NULL OR trusted = FALSE)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_device_profiles_tenant_user ON payment_gateway.device_profiles(tenant_id, user_id, last_seen DESC);
+CREATE INDEX IF NOT EXISTS idx_device_profiles_tenant_fingerprint ON payment_gateway.device_profiles(tenant_id, fingerprint);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.device_profiles IS 'Dispositivos conhecidos dos usuários usados na avaliação de confiança dos pagamentos';
+COMMENT ON COLUMN payment_gateway.device_profiles.fingerprint IS 'Impressão digital mais recente; muda quando o dispositivo é reconhecido por similaridade';
+COMMENT ON COLUMN payment_gateway.device_profiles.attributes IS 'Atributos adicionais do dispositivo comparados no cálculo de similaridade';
+COMMENT ON COLUMN payment_gateway.device_profiles.trusted IS 'Promovido após pagamentos aprovados; pode sustentar isenções de autenticação forte';
+COMMENT ON COLUMN payment_gateway.device_profiles.trust_revoked_at IS 'Revogação da confiança pelo suporte; impede a promoção automática';
@@ -0,0 +1,44 @@
+-- ==========================================================================
+-- Nome: V32__payment_gateway_transaction_records.sql
+-- Descrição: Migração para os registos das transações avaliadas pelo motor de
+--            risco do Payment Gateway (score, decisão e regras acionadas,
+--            consultados pelas equipas de suporte)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE REGISTOS DE TRANSAÇÕES
+-- ==========================================================================
+
+-- Transações avaliadas; não guarda os dados do meio de pagamento
+CREATE TABLE IF NOT EXISTS payment_gateway.transaction_records (
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    amount NUMERIC(20, 4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    risk_score DOUBLE PRECISION NOT NULL,
+    risk_explanation JSONB NOT NULL DEFAULT '{}',
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_transaction_records_risk_score CHECK (risk_score >= 0 AND risk_score <= 1)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_transaction_records_tenant_merchant ON payment_gateway.transaction_records(tenant_id, merchant_id, recorded_at DESC);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.transaction_records IS 'Transações avaliadas pelo motor de risco, com a explicação consultada pelo suporte';
+COMMENT ON COLUMN payment_gateway.transaction_records.risk_explanation IS 'Decisão, score e regras acionadas com as condições observadas e a orientação de remediação';
@@ -0,0 +1,48 @@
+-- ==========================================================================
+-- Nome: V33__payment_gateway_webhook_deliveries.sql
+-- Descrição: Migração para as entregas de webhooks dos eventos das transações
+--            aos comerciantes do Payment Gateway (novas tentativas, estado e
+--            reentregas pelas equipas de suporte)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE ENTREGAS DE WEBHOOKS
+-- ==========================================================================
+
+-- Entregas dos eventos aos endpoints dos comerciantes; retomadas após um reinício
+CREATE TABLE IF NOT EXISTS payment_gateway.webhook_deliveries (
+    delivery_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    event JSONB NOT NULL,
+    url TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    redeliveries INTEGER NOT NULL DEFAULT 0,
+    CONSTRAINT ck_webhook_deliveries_status CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON payment_gateway.webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_tenant_merchant ON payment_gateway.webhook_deliveries(tenant_id, merchant_id, created_at DESC);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.webhook_deliveries IS 'Entregas dos eventos das transações aos endpoints de notificação dos comerciantes';
+COMMENT ON COLUMN payment_gateway.webhook_deliveries.event IS 'Corpo JSON enviado ao comerciante, reutilizado em todas as tentativas';
+COMMENT ON COLUMN payment_gateway.webhook_deliveries.status IS 'pending: por entregar; delivered: aceite pelo endpoint; failed: tentativas esgotadas, requer reentrega manual';
@@ -0,0 +1,41 @@
+-- ==========================================================================
+-- Nome: V34__payment_gateway_remittances.sql
+-- Descrição: Migração para as remessas internacionais do Payment Gateway
+--            (débito, câmbio e crédito ao beneficiário por corredor)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE REMESSAS
+-- ==========================================================================
+
+-- Remessas executadas; uma por transação de pagamento
+CREATE TABLE IF NOT EXISTS payment_gateway.remittances (
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    corridor_id VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    fee NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    legs JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_remittances_status CHECK (status IN ('pending', 'completed', 'failed'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_remittances_corridor_status ON payment_gateway.remittances(corridor_id, status, created_at DESC);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.remittances IS 'Remessas internacionais executadas em três pernas: débito, câmbio e crédito';
+COMMENT ON COLUMN payment_gateway.remittances.legs IS 'Pernas da remessa com moeda, valor, taxa, estado e referência; débito e câmbio são estornados quando o crédito falha';
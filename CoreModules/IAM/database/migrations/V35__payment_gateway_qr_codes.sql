@@ -0,0 +1,51 @@
+-- ==========================================================================
+-- Nome: V35__payment_gateway_qr_codes.sql
+-- Descrição: Migração para os QR codes de pagamento do Payment Gateway
+--            (BR Code dinâmico do PIX e QR EMVCo com valor vinculado)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE QR CODES
+-- ==========================================================================
+
+-- QR codes gerados; o pagamento só é concluído com a confirmação do PSP
+CREATE TABLE IF NOT EXISTS payment_gateway.qr_codes (
+    id VARCHAR(35) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    format VARCHAR(20) NOT NULL,
+    payload TEXT NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    end_to_end_id VARCHAR(64) NOT NULL DEFAULT '',
+    CONSTRAINT ck_qr_codes_format CHECK (format IN ('pix_dynamic', 'emvco')),
+    CONSTRAINT ck_qr_codes_status CHECK (status IN ('active', 'paid', 'expired'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_qr_codes_active_expiry ON payment_gateway.qr_codes(expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_qr_codes_tenant_transaction ON payment_gateway.qr_codes(tenant_id, transaction_id);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.qr_codes IS 'QR codes de pagamento com valor vinculado, conciliados com as confirmações dos PSPs';
+COMMENT ON COLUMN payment_gateway.qr_codes.id IS 'Identificador do QR code, também usado como txid da cobrança PIX';
+COMMENT ON COLUMN payment_gateway.qr_codes.end_to_end_id IS 'Identificador da liquidação no PSP; confirmações repetidas com o mesmo valor são idempotentes';
@@ -0,0 +1,78 @@
+-- ==========================================================================
+-- Nome: V36__payment_gateway_sca_exemptions.sql
+-- Descrição: Migração para as isenções de autenticação forte do PSD2 no
+--            Payment Gateway (baixo valor, beneficiário de confiança e TRA)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE ISENÇÕES SCA
+-- ==========================================================================
+
+-- Decisões de isenção por pagamento, reportadas ao adquirente e base da taxa de fraude da TRA
+CREATE TABLE IF NOT EXISTS payment_gateway.sca_decisions (
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    instrument VARCHAR(20) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    exemption VARCHAR(30) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    fraud_rate NUMERIC(12, 8) NOT NULL DEFAULT 0,
+    fraud_rate_threshold NUMERIC(12, 8) NOT NULL DEFAULT 0,
+    risk_score NUMERIC(5, 4) NOT NULL DEFAULT 0,
+    low_value_count INTEGER NOT NULL DEFAULT 0,
+    low_value_cumulative NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    fraudulent BOOLEAN NOT NULL DEFAULT FALSE,
+    decided_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_sca_decisions_instrument CHECK (instrument IN ('card', 'credit_transfer')),
+    CONSTRAINT ck_sca_decisions_exemption CHECK (exemption IN ('none', 'low_value', 'trusted_beneficiary', 'tra')),
+    CONSTRAINT ck_sca_decisions_outcome CHECK (outcome IN ('exempted', 'sca_required', 'sca_performed'))
+);
+
+-- Pagamentos isentos por baixo valor desde a última SCA, por cartão ou conta do pagador
+CREATE TABLE IF NOT EXISTS payment_gateway.sca_low_value_counters (
+    tenant_id VARCHAR(255) NOT NULL,
+    instrument_key VARCHAR(255) NOT NULL,
+    count INTEGER NOT NULL,
+    cumulative NUMERIC(20, 2) NOT NULL,
+    PRIMARY KEY (tenant_id, instrument_key)
+);
+
+-- Beneficiários adicionados pelo pagador à lista de confiança num pagamento autenticado com SCA
+CREATE TABLE IF NOT EXISTS payment_gateway.sca_trusted_beneficiaries (
+    tenant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    beneficiary_key VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, beneficiary_key)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_sca_decisions_decided_at ON payment_gateway.sca_decisions(decided_at);
+CREATE INDEX IF NOT EXISTS idx_sca_decisions_volume ON payment_gateway.sca_decisions(tenant_id, instrument, decided_at) WHERE completed;
+CREATE INDEX IF NOT EXISTS idx_sca_decisions_merchant ON payment_gateway.sca_decisions(tenant_id, merchant_id, decided_at);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.sca_decisions IS 'Decisões de isenção SCA do PSD2 (RTS, Regulamento Delegado (UE) 2018/389) por pagamento';
+COMMENT ON COLUMN payment_gateway.sca_decisions.completed IS 'Pagamento concluído; entra no volume da taxa de fraude do instrumento';
+COMMENT ON COLUMN payment_gateway.sca_decisions.fraudulent IS 'Fraude reportada; entra na fraude da taxa usada na isenção TRA';
+COMMENT ON TABLE payment_gateway.sca_low_value_counters IS 'Contadores da isenção de baixo valor (Art. 16), reiniciados a cada SCA';
+COMMENT ON TABLE payment_gateway.sca_trusted_beneficiaries IS 'Listas de beneficiários de confiança dos pagadores (Art. 13)';
@@ -0,0 +1,57 @@
+-- ==========================================================================
+-- Nome: V37__payment_gateway_instalment_plans.sql
+-- Descrição: Migração para os planos de parcelamento do Payment Gateway
+--            (tabela Price, captura das parcelas e liquidação antecipada)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE PARCELAMENTO
+-- ==========================================================================
+
+-- Planos de parcelamento; as parcelas e a liquidação antecipada são gravadas em JSONB
+CREATE TABLE IF NOT EXISTS payment_gateway.instalment_plans (
+    id VARCHAR(64) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    funding_method VARCHAR(50) NOT NULL,
+    card_token_id VARCHAR(255) NOT NULL DEFAULT '',
+    principal NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    instalment_count INTEGER NOT NULL,
+    interest_free BOOLEAN NOT NULL,
+    monthly_rate NUMERIC(8, 6) NOT NULL,
+    annual_rate NUMERIC(8, 4) NOT NULL,
+    total_interest NUMERIC(20, 2) NOT NULL,
+    total_amount NUMERIC(20, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    instalments JSONB NOT NULL DEFAULT '[]',
+    early_settlement JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    delinquent_since TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (tenant_id, id),
+    CONSTRAINT ck_instalment_plans_status CHECK (status IN ('active', 'delinquent', 'settled', 'cancelled')),
+    CONSTRAINT ck_instalment_plans_count CHECK (instalment_count >= 2)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_instalment_plans_open ON payment_gateway.instalment_plans(tenant_id, user_id) WHERE status IN ('active', 'delinquent');
+CREATE INDEX IF NOT EXISTS idx_instalment_plans_transaction ON payment_gateway.instalment_plans(tenant_id, transaction_id);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.instalment_plans IS 'Planos de parcelamento das compras, com a primeira parcela capturada na compra';
+COMMENT ON COLUMN payment_gateway.instalment_plans.annual_rate IS 'Taxa anual equivalente, divulgada como CET (Brasil) ou TAEG (UE)';
+COMMENT ON COLUMN payment_gateway.instalment_plans.delinquent_since IS 'Início do incumprimento: parcela em atraso além da tolerância do mercado';
@@ -0,0 +1,48 @@
+-- ==========================================================================
+-- Nome: V38__payment_gateway_psp_authorizations.sql
+-- Descrição: Migração para as autorizações do PSP do Payment Gateway
+--            (conciliação dos webhooks das autorizações pendentes)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE AUTORIZAÇÕES DO PSP
+-- ==========================================================================
+
+-- Autorizações aprovadas ou pendentes no PSP, indexadas pela referência atribuída pelo PSP
+CREATE TABLE IF NOT EXISTS payment_gateway.psp_authorizations (
+    reference VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    response_code VARCHAR(10) NOT NULL DEFAULT '',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    asynchronous BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT ck_psp_authorizations_status CHECK (status IN ('approved', 'declined', 'pending'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_psp_authorizations_transaction ON payment_gateway.psp_authorizations(tenant_id, transaction_id);
+CREATE INDEX IF NOT EXISTS idx_psp_authorizations_pending ON payment_gateway.psp_authorizations(created_at) WHERE status = 'pending';
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.psp_authorizations IS 'Autorizações enviadas ao PSP, concluídas pelos webhooks quando recebidas pendentes';
+COMMENT ON COLUMN payment_gateway.psp_authorizations.response_code IS 'Código de resposta ISO 8583 do emissor';
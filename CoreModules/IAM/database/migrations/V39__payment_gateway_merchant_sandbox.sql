@@ -0,0 +1,41 @@
+-- ==========================================================================
+-- Nome: V39__payment_gateway_merchant_sandbox.sql
+-- Descrição: Migração para o modo sandbox dos comerciantes do Payment Gateway
+--            (pagamentos autorizados no simulador de PSP, sem movimento de fundos)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DO SANDBOX
+-- ==========================================================================
+
+-- Modo sandbox de cada comerciante, alterado pelas equipas de suporte
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_sandbox (
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, merchant_id)
+);
+
+-- Os pagamentos suspensos por step-up terminam no ambiente em que começaram
+ALTER TABLE payment_gateway.payment_step_ups
+    ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_merchant_sandbox_enabled ON payment_gateway.merchant_sandbox(tenant_id) WHERE enabled;
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.merchant_sandbox IS 'Comerciantes em modo sandbox: pagamentos autorizados no simulador de PSP, com dados e métricas isolados';
+COMMENT ON COLUMN payment_gateway.merchant_sandbox.updated_by IS 'Operador de suporte que fez a última alteração';
@@ -0,0 +1,85 @@
+-- ==========================================================================
+-- Nome: V40__payment_gateway_fraud_feedback.sql
+-- Descrição: Migração para o ciclo de feedback de fraude do Payment Gateway
+--            (rótulos confirmados pelos emissores e comerciantes, perfil de
+--            risco dos comerciantes e auditoria dos seus ajustes automáticos)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DO FEEDBACK DE FRAUDE
+-- ==========================================================================
+
+-- Rótulo atual de cada transação avaliada pelo motor de risco
+CREATE TABLE IF NOT EXISTS payment_gateway.fraud_feedback (
+    feedback_id VARCHAR(255) NOT NULL UNIQUE,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL DEFAULT '',
+    label VARCHAR(20) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    reported_by VARCHAR(255) NOT NULL DEFAULT '',
+    previous_label VARCHAR(20) NOT NULL DEFAULT '',
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, transaction_id),
+    CONSTRAINT ck_fraud_feedback_label CHECK (label IN ('fraud', 'legit')),
+    CONSTRAINT ck_fraud_feedback_source CHECK (source IN ('issuer', 'merchant'))
+);
+
+-- Perfil de risco de cada comerciante ajustado pelos rótulos confirmados
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_risk_profiles (
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    transactions BIGINT NOT NULL DEFAULT 0,
+    confirmed_fraud BIGINT NOT NULL DEFAULT 0,
+    confirmed_legit BIGINT NOT NULL DEFAULT 0,
+    fraud_amount NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    fraud_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    level VARCHAR(20) NOT NULL DEFAULT 'low',
+    risk_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, merchant_id),
+    CONSTRAINT ck_merchant_risk_profiles_level CHECK (level IN ('low', 'elevated', 'high', 'critical'))
+);
+
+-- Auditoria das alterações automáticas do nível de risco dos comerciantes
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_risk_adjustments (
+    adjustment_id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    feedback_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    previous_level VARCHAR(20) NOT NULL,
+    new_level VARCHAR(20) NOT NULL,
+    previous_score DOUBLE PRECISION NOT NULL,
+    new_score DOUBLE PRECISION NOT NULL,
+    fraud_rate DOUBLE PRECISION NOT NULL,
+    reason TEXT NOT NULL,
+    adjusted_by VARCHAR(255) NOT NULL DEFAULT '',
+    adjusted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- O dispositivo da transação avaliada identifica a chave de velocidade dos rótulos
+ALTER TABLE payment_gateway.transaction_records
+    ADD COLUMN IF NOT EXISTS device_fingerprint VARCHAR(255) NOT NULL DEFAULT '';
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_fraud_feedback_tenant_merchant ON payment_gateway.fraud_feedback(tenant_id, merchant_id, reported_at);
+CREATE INDEX IF NOT EXISTS idx_merchant_risk_adjustments_merchant ON payment_gateway.merchant_risk_adjustments(tenant_id, merchant_id, adjusted_at);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.fraud_feedback IS 'Rótulos de fraude confirmados pelos emissores e comerciantes após o processamento';
+COMMENT ON COLUMN payment_gateway.fraud_feedback.previous_label IS 'Rótulo substituído quando a transação é reclassificada';
+COMMENT ON TABLE payment_gateway.merchant_risk_profiles IS 'Perfil de risco dos comerciantes usado pela regra merchant_risk_profile';
+COMMENT ON TABLE payment_gateway.merchant_risk_adjustments IS 'Auditoria de cada ajuste automático do nível de risco dos comerciantes';
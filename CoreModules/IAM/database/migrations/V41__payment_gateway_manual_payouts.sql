@@ -0,0 +1,77 @@
+-- ==========================================================================
+-- Nome: V41__payment_gateway_manual_payouts.sql
+-- Descrição: Migração para os pagamentos manuais com controlo duplo do
+--            Payment Gateway (pagamentos iniciados pelas equipas de operações,
+--            decisões do segundo operador e cadeia de auditoria por tenant)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DOS PAGAMENTOS MANUAIS
+-- ==========================================================================
+
+-- Pagamentos manuais e as decisões dos operadores
+CREATE TABLE IF NOT EXISTS payment_gateway.manual_payouts (
+    payout_id VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    market VARCHAR(20) NOT NULL,
+    partner VARCHAR(255) NOT NULL,
+    amount NUMERIC(20, 4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    beneficiary JSONB NOT NULL,
+    purpose_code VARCHAR(50) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    dual_control BOOLEAN NOT NULL,
+    threshold NUMERIC(20, 4) NOT NULL,
+    initiated_by VARCHAR(255) NOT NULL,
+    initiator_role VARCHAR(100) NOT NULL,
+    decided_by VARCHAR(255) NOT NULL DEFAULT '',
+    decider_role VARCHAR(100) NOT NULL DEFAULT '',
+    rejection_reason TEXT NOT NULL DEFAULT '',
+    partner_reference VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    approval_due_at TIMESTAMP WITH TIME ZONE,
+    sla_breached BOOLEAN NOT NULL DEFAULT FALSE,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    executed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (tenant_id, payout_id),
+    CONSTRAINT ck_manual_payouts_status CHECK (status IN ('pending_approval', 'approved', 'executed', 'rejected', 'failed')),
+    CONSTRAINT ck_manual_payouts_distinct_decider CHECK (decided_by = '' OR (decided_by <> initiated_by AND decider_role <> initiator_role))
+);
+
+-- Cadeia de auditoria dos pagamentos manuais: cada registo inclui o hash do anterior
+CREATE TABLE IF NOT EXISTS payment_gateway.manual_payout_audit (
+    tenant_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    payout_id VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    role VARCHAR(100) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    PRIMARY KEY (tenant_id, sequence),
+    CONSTRAINT ck_manual_payout_audit_action CHECK (action IN ('initiated', 'approved', 'rejected', 'executed', 'failed'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_manual_payouts_status ON payment_gateway.manual_payouts(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_manual_payout_audit_payout ON payment_gateway.manual_payout_audit(tenant_id, payout_id, sequence);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.manual_payouts IS 'Pagamentos manuais das equipas de operações, com controlo duplo acima do limiar do mercado';
+COMMENT ON COLUMN payment_gateway.manual_payouts.sla_breached IS 'Alerta de SLA de aprovação já emitido para o pagamento pendente';
+COMMENT ON TABLE payment_gateway.manual_payout_audit IS 'Cadeia de auditoria dos pagamentos manuais ligada por hash SHA-256, uma por tenant';
@@ -0,0 +1,57 @@
+-- ==========================================================================
+-- Nome: V42__payment_gateway_scheduled_payments.sql
+-- Descrição: Migração para os pagamentos agendados do Payment Gateway
+--            (pagamentos únicos com data futura, pré-autorizados no
+--            agendamento e revalidados no dia útil de execução)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DOS PAGAMENTOS AGENDADOS
+-- ==========================================================================
+
+-- Pagamentos agendados e o pedido submetido ao processamento na data de execução
+CREATE TABLE IF NOT EXISTS payment_gateway.scheduled_payments (
+    id VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    amount NUMERIC(20, 4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    market VARCHAR(20) NOT NULL DEFAULT '',
+    requested_date DATE NOT NULL,
+    execution_date DATE NOT NULL,
+    cancellable_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    cancelled_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    executed_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    request JSONB NOT NULL,
+    PRIMARY KEY (tenant_id, id),
+    CONSTRAINT ck_scheduled_payments_status CHECK (status IN ('scheduled', 'executing', 'executed', 'failed', 'cancelled')),
+    CONSTRAINT ck_scheduled_payments_execution CHECK (execution_date >= requested_date)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_payments_due ON payment_gateway.scheduled_payments(execution_date) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_scheduled_payments_user ON payment_gateway.scheduled_payments(tenant_id, user_id, execution_date);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.scheduled_payments IS 'Pagamentos únicos agendados pelos clientes para um dia útil futuro do mercado';
+COMMENT ON COLUMN payment_gateway.scheduled_payments.execution_date IS 'Dia útil de execução no calendário de feriados bancários do mercado';
+COMMENT ON COLUMN payment_gateway.scheduled_payments.cancellable_until IS 'Fim do prazo de cancelamento pelo cliente';
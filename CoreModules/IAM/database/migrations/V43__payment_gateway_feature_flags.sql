@@ -0,0 +1,44 @@
+-- ==========================================================================
+-- Nome: V43__payment_gateway_feature_flags.sql
+-- Descrição: Migração para a matriz de feature flags do Payment Gateway
+--            (alterações em tempo de execução por mercado, meio de pagamento
+--            e nível de comerciante, e níveis atribuídos aos comerciantes)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DA MATRIZ DE FEATURE FLAGS
+-- ==========================================================================
+
+-- Alterações em tempo de execução das células da matriz ('*' corresponde a qualquer valor)
+CREATE TABLE IF NOT EXISTS payment_gateway.feature_flag_overrides (
+    market VARCHAR(20) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL,
+    merchant_tier VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (market, payment_method, merchant_tier),
+    CONSTRAINT ck_feature_flag_overrides_tier CHECK (merchant_tier IN ('*', 'standard', 'premium', 'enterprise'))
+);
+
+-- Níveis atribuídos aos comerciantes pela API de suporte
+CREATE TABLE IF NOT EXISTS payment_gateway.merchant_tiers (
+    merchant_id VARCHAR(255) NOT NULL PRIMARY KEY,
+    tier VARCHAR(20) NOT NULL,
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT ck_merchant_tiers_tier CHECK (tier IN ('standard', 'premium', 'enterprise'))
+);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.feature_flag_overrides IS 'Alterações em tempo de execução da matriz de feature flags, com precedência sobre a configuração e a origem remota';
+COMMENT ON TABLE payment_gateway.merchant_tiers IS 'Nível de cada comerciante usado na matriz de feature flags';
@@ -0,0 +1,127 @@
+-- ==========================================================================
+-- Nome: V44__payment_gateway_pos_terminals.sql
+-- Descrição: Migração para os terminais EFTPOS do Payment Gateway
+--            (terminais e chaves cifradas, componentes da TMK por compor,
+--            autorizações, avisos offline e lotes de liquidação)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DOS TERMINAIS EFTPOS
+-- ==========================================================================
+
+-- Terminais registados, com a TMK e as chaves de sessão cifradas com a chave do gateway
+CREATE TABLE IF NOT EXISTS payment_gateway.pos_terminals (
+    terminal_id VARCHAR(8) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    card_acceptor_id VARCHAR(15) NOT NULL,
+    region_code VARCHAR(10) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    serial_number VARCHAR(100) NOT NULL,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    status VARCHAR(30) NOT NULL,
+    offline_limits JSONB NOT NULL,
+    master_key_kcv VARCHAR(6) NOT NULL DEFAULT '',
+    key_custodians JSONB NOT NULL DEFAULT '[]',
+    key_injected_at TIMESTAMP WITH TIME ZONE,
+    session_keys_issued_at TIMESTAMP WITH TIME ZONE,
+    session_keys_expire_at TIMESTAMP WITH TIME ZONE,
+    registered_by VARCHAR(255) NOT NULL DEFAULT '',
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    master_key BYTEA,
+    pin_key BYTEA,
+    mac_key BYTEA,
+    CONSTRAINT ck_pos_terminals_status CHECK (status IN ('pending_key_injection', 'active', 'suspended', 'decommissioned'))
+);
+
+-- Componentes da TMK recebidos e ainda não compostos (controlo duplo)
+CREATE TABLE IF NOT EXISTS payment_gateway.pos_key_components (
+    terminal_id VARCHAR(8) NOT NULL REFERENCES payment_gateway.pos_terminals (terminal_id),
+    custodian VARCHAR(255) NOT NULL,
+    master_kcv VARCHAR(6) NOT NULL,
+    component BYTEA NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (terminal_id, custodian)
+);
+
+-- Desfecho das vendas dos terminais
+CREATE TABLE IF NOT EXISTS payment_gateway.pos_authorizations (
+    transaction_id VARCHAR(255) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    terminal_id VARCHAR(8) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    stan VARCHAR(6) NOT NULL,
+    rrn VARCHAR(12) NOT NULL,
+    auth_code VARCHAR(6) NOT NULL DEFAULT '',
+    response_code VARCHAR(2) NOT NULL DEFAULT '',
+    approved BOOLEAN NOT NULL,
+    offline BOOLEAN NOT NULL DEFAULT FALSE,
+    offline_reason VARCHAR(30) NOT NULL DEFAULT '',
+    masked_pan VARCHAR(19) NOT NULL,
+    amount NUMERIC(18, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    batch_number INTEGER NOT NULL DEFAULT 0,
+    authorized_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Avisos (0220) das vendas aprovadas offline, por encaminhar ou encaminhados à rede
+CREATE TABLE IF NOT EXISTS payment_gateway.pos_advices (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    terminal_id VARCHAR(8) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    amount NUMERIC(18, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code VARCHAR(2) NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    forwarded_at TIMESTAMP WITH TIME ZONE,
+    message JSONB NOT NULL,
+    CONSTRAINT ck_pos_advices_status CHECK (status IN ('pending', 'forwarded', 'rejected'))
+);
+
+-- Lotes de liquidação dos terminais
+CREATE TABLE IF NOT EXISTS payment_gateway.pos_batches (
+    terminal_id VARCHAR(8) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    batch_number INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    totals JSONB NOT NULL,
+    terminal_totals JSONB,
+    entries JSONB NOT NULL DEFAULT '[]',
+    uploaded BOOLEAN NOT NULL DEFAULT FALSE,
+    response_code VARCHAR(2) NOT NULL DEFAULT '',
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (terminal_id, batch_number),
+    CONSTRAINT ck_pos_batches_status CHECK (status IN ('open', 'closing', 'settled', 'out_of_balance'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_pos_terminals_merchant ON payment_gateway.pos_terminals (merchant_id);
+CREATE INDEX IF NOT EXISTS idx_pos_authorizations_terminal ON payment_gateway.pos_authorizations (terminal_id, authorized_at);
+CREATE INDEX IF NOT EXISTS idx_pos_advices_pending ON payment_gateway.pos_advices (terminal_id, created_at) WHERE status = 'pending';
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.pos_terminals IS 'Terminais EFTPOS dos comerciantes ligados às redes EMIS (Angola) e SIMO (Moçambique)';
+COMMENT ON COLUMN payment_gateway.pos_terminals.master_key IS 'TMK cifrada com AES-256-GCM pela chave de cifra do gateway';
+COMMENT ON TABLE payment_gateway.pos_key_components IS 'Componentes da TMK por compor, cifrados, de custódios distintos';
+COMMENT ON TABLE payment_gateway.pos_authorizations IS 'Desfecho das vendas EFTPOS, com o PAN mascarado';
+COMMENT ON TABLE payment_gateway.pos_advices IS 'Avisos das vendas aprovadas offline por encaminhar à rede';
+COMMENT ON TABLE payment_gateway.pos_batches IS 'Lotes de liquidação dos terminais com os totais e as vendas incluídas';
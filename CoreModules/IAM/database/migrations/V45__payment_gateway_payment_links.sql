@@ -0,0 +1,56 @@
+-- ==========================================================================
+-- Nome: V45__payment_gateway_payment_links.sql
+-- Descrição: Migração para os links de pagamento do Payment Gateway
+--            (links partilháveis das faturas com parâmetros assinados e
+--            tentativas de pagamento no checkout alojado)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DOS LINKS DE PAGAMENTO
+-- ==========================================================================
+
+-- Links de pagamento das faturas dos comerciantes
+CREATE TABLE IF NOT EXISTS payment_gateway.payment_links (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    amount NUMERIC(18, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    invoice_reference VARCHAR(255) NOT NULL DEFAULT '',
+    payment_methods JSONB NOT NULL,
+    url TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    attempts JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    cancelled_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT ck_payment_links_status CHECK (status IN ('active', 'processing', 'paid', 'expired', 'cancelled')),
+    CONSTRAINT ck_payment_links_amount CHECK (amount > 0)
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_payment_links_merchant ON payment_gateway.payment_links (tenant_id, merchant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_links_active_expiry ON payment_gateway.payment_links (expires_at) WHERE status = 'active';
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.payment_links IS 'Links de pagamento partilháveis das faturas, pagos no checkout alojado';
+COMMENT ON COLUMN payment_gateway.payment_links.url IS 'URL do checkout com o valor, a moeda e a validade assinados por HMAC-SHA256';
+COMMENT ON COLUMN payment_gateway.payment_links.attempts IS 'Últimas tentativas de pagamento no checkout, aprovadas ou recusadas';
@@ -0,0 +1,76 @@
+-- ==========================================================================
+-- Nome: V46__payment_gateway_stored_credentials.sql
+-- Descrição: Migração para as credenciais armazenadas do Payment Gateway
+--            (cartões card-on-file com o consentimento do titular, cadeia de
+--            auditoria do consentimento e da utilização, e ID na rede das
+--            autorizações do PSP referido pelas MIT)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DAS CREDENCIAIS ARMAZENADAS
+-- ==========================================================================
+
+-- Cartões armazenados pelos comerciantes com o consentimento do titular
+CREATE TABLE IF NOT EXISTS payment_gateway.stored_credentials (
+    id VARCHAR(255) NOT NULL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    masked_pan VARCHAR(32) NOT NULL,
+    expiry_month INTEGER NOT NULL,
+    expiry_year INTEGER NOT NULL,
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    consent JSONB NOT NULL,
+    initial_transaction_id VARCHAR(255) NOT NULL,
+    network_transaction_id VARCHAR(255) NOT NULL,
+    encrypted_pan BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT ck_stored_credentials_status CHECK (status IN ('active', 'expired', 'closed', 'revoked')),
+    CONSTRAINT ck_stored_credentials_expiry_month CHECK (expiry_month BETWEEN 1 AND 12)
+);
+
+-- Cadeia de auditoria do consentimento e da utilização das credenciais, uma por tenant
+CREATE TABLE IF NOT EXISTS payment_gateway.stored_credential_audit (
+    tenant_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    credential_id VARCHAR(255) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    PRIMARY KEY (tenant_id, sequence)
+);
+
+-- ID na rede das autorizações do PSP, referido pelas MIT seguintes com o cartão armazenado
+ALTER TABLE payment_gateway.psp_authorizations ADD COLUMN IF NOT EXISTS network_transaction_id VARCHAR(255) NOT NULL DEFAULT '';
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_stored_credentials_user ON payment_gateway.stored_credentials (tenant_id, user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stored_credentials_status_expiry ON payment_gateway.stored_credentials (status, expiry_year, expiry_month);
+CREATE INDEX IF NOT EXISTS idx_stored_credential_audit_credential ON payment_gateway.stored_credential_audit (tenant_id, credential_id, sequence);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.stored_credentials IS 'Cartões armazenados (card-on-file) com o consentimento do titular, usados em CIT e MIT';
+COMMENT ON COLUMN payment_gateway.stored_credentials.encrypted_pan IS 'PAN cifrado com AES-256-GCM; apagado quando o consentimento é revogado ou a conta encerrada';
+COMMENT ON COLUMN payment_gateway.stored_credentials.network_transaction_id IS 'ID na rede da CIT inicial, exigido nas MIT';
+COMMENT ON TABLE payment_gateway.stored_credential_audit IS 'Cadeia de auditoria das credenciais armazenadas ligada por hash SHA-256, uma por tenant';
@@ -0,0 +1,58 @@
+-- ==========================================================================
+-- Nome: V47__payment_gateway_crypto_payments.sql
+-- Descrição: Migração para os pagamentos em criptoativos do Payment Gateway
+--            (cotação bloqueada, depósitos acompanhados até às confirmações
+--            exigidas pela rede e dados da travel rule do GAFI)
+-- Autor: Equipa de Desenvolvimento INNOVABIZ
+-- Data: 16/10/2026
+-- ==========================================================================
+
+-- Assegurar que o esquema do gateway de pagamentos existe
+CREATE SCHEMA IF NOT EXISTS payment_gateway;
+
+-- ==========================================================================
+-- TABELAS DE PAGAMENTOS EM CRIPTOATIVOS
+-- ==========================================================================
+
+-- Pagamentos em criptoativos; o pagamento só é concluído com as confirmações exigidas pela rede
+CREATE TABLE IF NOT EXISTS payment_gateway.crypto_payments (
+    id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    transaction_id VARCHAR(255) NOT NULL,
+    merchant_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    region_code VARCHAR(10) NOT NULL DEFAULT '',
+    asset VARCHAR(10) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    fiat_amount NUMERIC(20, 2) NOT NULL,
+    fiat_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(30, 8) NOT NULL,
+    crypto_amount NUMERIC(30, 8) NOT NULL,
+    received_amount NUMERIC(30, 8) NOT NULL DEFAULT 0,
+    payer_address VARCHAR(128) NOT NULL,
+    deposit_address VARCHAR(128) NOT NULL,
+    required_confirmations INTEGER NOT NULL,
+    confirmations INTEGER NOT NULL DEFAULT 0,
+    tx_hashes JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL,
+    travel_rule JSONB NOT NULL,
+    quoted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT ck_crypto_payments_status CHECK (status IN ('awaiting_deposit', 'confirming', 'confirmed', 'expired', 'underpaid'))
+);
+
+-- ==========================================================================
+-- ÍNDICES
+-- ==========================================================================
+
+CREATE INDEX IF NOT EXISTS idx_crypto_payments_pending ON payment_gateway.crypto_payments(quoted_at) WHERE status IN ('awaiting_deposit', 'confirming');
+CREATE INDEX IF NOT EXISTS idx_crypto_payments_tenant_transaction ON payment_gateway.crypto_payments(tenant_id, transaction_id, quoted_at DESC);
+
+-- ==========================================================================
+-- COMENTÁRIOS
+-- ==========================================================================
+
+COMMENT ON TABLE payment_gateway.crypto_payments IS 'Pagamentos em criptoativos com cotação bloqueada, acompanhados até às confirmações na rede';
+COMMENT ON COLUMN payment_gateway.crypto_payments.rate IS 'Preço do ativo bloqueado durante a janela da cotação';
+COMMENT ON COLUMN payment_gateway.crypto_payments.travel_rule IS 'Avaliação e troca de dados da travel rule (Recomendação 16 do GAFI)';
@@ -1,6 +1,7 @@
 package bureaucredito
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
 	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
 	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
 )
 
 // TrustGuardConnector é o adaptador para integração com o serviço TrustGuard
@@ -22,9 +24,7 @@ type TrustGuardConnector struct {
 	logger          logging.Logger
 	metrics         metrics.Metrics
 	tracer          tracing.Tracer
-	defaultTimeout  time.Duration
-	retryAttempts   int
-	retryDelay      time.Duration
+	policy          resilience.Policy
 }
 
 // TrustGuardConfig é a configuração do conector TrustGuard
@@ -34,6 +34,7 @@ type TrustGuardConfig struct {
 	Timeout        time.Duration
 	RetryAttempts  int
 	RetryDelay     time.Duration
+	Resilience     resilience.Policy // Sobrepõe Timeout, RetryAttempts e RetryDelay
 	Logger         logging.Logger
 	MetricsClient  metrics.Metrics
 	TracingClient  tracing.Tracer
@@ -111,16 +112,21 @@ func NewTrustGuardConnector(cfg TrustGuardConfig) (*TrustGuardConnector, error)
 		tracingClient = tracing.NewNoOpTracer()
 	}
 
+	// RetryAttempts conta as repetições, além da primeira tentativa
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{
+		Timeout:        timeout,
+		MaxAttempts:    retryAttempts + 1,
+		InitialBackoff: retryDelay,
+	}).Merge(cfg.Resilience)
+
 	return &TrustGuardConnector{
-		apiBaseURL:     cfg.APIURL,
-		apiKey:         cfg.APIKey,
-		httpClient:     &http.Client{Timeout: timeout},
-		logger:         logger,
-		metrics:        metricsClient,
-		tracer:         tracingClient,
-		defaultTimeout: timeout,
-		retryAttempts:  retryAttempts,
-		retryDelay:     retryDelay,
+		apiBaseURL: cfg.APIURL,
+		apiKey:     cfg.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		logger:     logger,
+		metrics:    metricsClient,
+		tracer:     tracingClient,
+		policy:     policy,
 	}, nil
 }
 
@@ -166,35 +172,21 @@ func (c *TrustGuardConnector) VerifyIdentity(ctx context.Context, req *Verificat
 		return nil, fmt.Errorf("falha ao serializar requisição: %w", err)
 	}
 
-	// Executar requisição com retry
+	// Executar requisição com retry segundo a política de resiliência
 	var response *VerificationResponse
-	var lastError error
-
-	for attempt := 0; attempt <= c.retryAttempts; attempt++ {
-		// Se não for a primeira tentativa, aguardar antes de retry
-		if attempt > 0 {
-			select {
-			case <-time.After(c.retryDelay * time.Duration(attempt)):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		}
-
-		// Executar a verificação
-		response, lastError = c.executeVerification(ctx, payload)
-		if lastError == nil {
-			// Sucesso, retornar resposta
-			break
-		}
-
+	lastError := resilience.Do(ctx, c.policy, func(ctx context.Context) error {
+		var err error
+		response, err = c.executeVerification(ctx, payload)
+		return err
+	}, resilience.OnRetry(func(attempt resilience.Attempt) {
 		c.logger.Warn(
 			"Tentativa de verificação falhou, executando retry",
-			logging.String("error", lastError.Error()),
-			logging.Int("attempt", attempt),
-			logging.Int("maxAttempts", c.retryAttempts),
+			logging.String("error", attempt.Err.Error()),
+			logging.Int("attempt", attempt.Number),
+			logging.Int("maxAttempts", c.policy.MaxAttempts),
 			logging.String("userId", req.UserID),
 		)
-	}
+	}))
 
 	// Se ainda tiver erro após todas as tentativas
 	if lastError != nil {
@@ -205,7 +197,7 @@ func (c *TrustGuardConnector) VerifyIdentity(ctx context.Context, req *Verificat
 			logging.String("verificationType", req.VerificationType),
 		)
 		c.metrics.IncrementCounter("iam.trustguard.error", metrics.Tag{Key: "error_type", Value: "max_retries_exceeded"})
-		return nil, fmt.Errorf("verificação de identidade falhou após %d tentativas: %w", c.retryAttempts, lastError)
+		return nil, fmt.Errorf("verificação de identidade falhou após %d tentativas: %w", c.policy.MaxAttempts, lastError)
 	}
 
 	// Verificação bem-sucedida
@@ -232,9 +224,9 @@ func (c *TrustGuardConnector) executeVerification(ctx context.Context, payload [
 	url := fmt.Sprintf("%s/api/v1/verify", c.apiBaseURL)
 
 	// Criar request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return nil, resilience.Permanent(fmt.Errorf("erro ao criar requisição: %w", err))
 	}
 
 	// Configurar headers
@@ -250,9 +242,13 @@ func (c *TrustGuardConnector) executeVerification(ctx context.Context, payload [
 	}
 	defer resp.Body.Close()
 
-	// Verificar status code
+	// Verificar status code; erros do pedido (4xx) não são repetidos
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TrustGuard retornou status code inválido: %d", resp.StatusCode)
+		err := fmt.Errorf("TrustGuard retornou status code inválido: %d", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+			return nil, resilience.Permanent(err)
+		}
+		return nil, err
 	}
 
 	// Processar resposta
@@ -311,18 +307,18 @@ func (c *TrustGuardConnector) GetVerificationStatus(ctx context.Context, verific
 	// Construir URL
 	url := fmt.Sprintf("%s/api/v1/verification/%s", c.apiBaseURL, verificationID)
 
-	// Criar request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
-	}
-
-	// Configurar headers
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("X-Request-ID", constants.GetRequestIDFromContext(ctx))
+	// Executar requisição; a consulta é idempotente e repetida segundo a política
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		}
 
-	// Executar requisição
-	resp, err := c.httpClient.Do(req)
+		// Configurar headers
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+		req.Header.Set("X-Request-ID", constants.GetRequestIDFromContext(ctx))
+		return req, nil
+	})
 	if err != nil {
 		c.metrics.IncrementCounter("iam.trustguard.error", metrics.Tag{Key: "error_type", Value: "communication_error"})
 		return nil, fmt.Errorf("erro de comunicação com TrustGuard: %w", err)
@@ -0,0 +1,82 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// AccountUpdater consulta as atualizações das credenciais armazenadas junto das redes
+type AccountUpdater interface {
+	// Inquire envia as credenciais a consultar e retorna as atualizações encontradas
+	Inquire(ctx context.Context, inquiries []AccountUpdaterInquiry) ([]AccountUpdaterResult, error)
+}
+
+// HTTPAccountUpdater envia as consultas ao serviço de account updater do adquirente por HTTP
+type HTTPAccountUpdater struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPAccountUpdater cria o cliente do account updater configurado em AccountUpdaterURL
+func NewHTTPAccountUpdater(config StoredCredentialConfig) *HTTPAccountUpdater {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultAccountUpdaterTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPAccountUpdater{
+		endpoint:   config.AccountUpdaterURL,
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Inquire envia as credenciais num único POST JSON
+func (u *HTTPAccountUpdater) Inquire(ctx context.Context, inquiries []AccountUpdaterInquiry) ([]AccountUpdaterResult, error) {
+	payload, err := json.Marshal(map[string]interface{}{"inquiries": inquiries})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao serializar consultas ao account updater: %w", err)
+	}
+
+	resp, err := resilience.DoHTTP(ctx, u.httpClient, u.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u.endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if u.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+u.apiKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("account updater indisponível: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("account updater indisponível: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("account updater retornou status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []AccountUpdaterResult `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("resposta inválida do account updater: %w", err)
+	}
+	return result.Results, nil
+}
@@ -0,0 +1,90 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// AcquirerClient define a chamada de autorização aos adquirentes
+type AcquirerClient interface {
+	// Authorize envia o pedido ao adquirente; aprovações e recusas são resultados, enquanto falhas
+	// de rede e respostas 5xx retornam ErrAcquirerUnavailable e respostas 4xx ErrAcquirerRequestRejected
+	Authorize(ctx context.Context, acquirer AcquirerConfig, req *AcquirerAuthorizationRequest) (*AcquirerAuthorizationResult, error)
+}
+
+// HTTPAcquirerClient chama as APIs de autorização dos adquirentes por HTTP/JSON
+type HTTPAcquirerClient struct {
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPAcquirerClient cria o cliente das APIs dos adquirentes
+func NewHTTPAcquirerClient(config AcquirerRoutingConfig) *HTTPAcquirerClient {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultAcquirerHTTPTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPAcquirerClient{
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Authorize envia um POST JSON para /authorizations do adquirente
+// A chave de idempotência combina a transação e o adquirente, para que as novas tentativas no mesmo
+// adquirente não dupliquem a autorização. O corpo pode conter o token e o criptograma do cartão e
+// nunca é incluído nos erros
+func (c *HTTPAcquirerClient) Authorize(ctx context.Context, acquirer AcquirerConfig, req *AcquirerAuthorizationRequest) (*AcquirerAuthorizationResult, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	target := strings.TrimRight(acquirer.APIURL, "/") + "/authorizations"
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, req.TransactionID+"-"+acquirer.AcquirerID)
+		if acquirer.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+acquirer.APIKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrAcquirerUnavailable, acquirer.AcquirerID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrAcquirerUnavailable, acquirer.AcquirerID, err)
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: %s retornou status %d", ErrAcquirerUnavailable, acquirer.AcquirerID, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("%w: %s retornou status %d", ErrAcquirerRequestRejected, acquirer.AcquirerID, resp.StatusCode)
+	}
+
+	var result AcquirerAuthorizationResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%w: %s: resposta inválida", ErrAcquirerUnavailable, acquirer.AcquirerID)
+	}
+	if !result.Approved && result.ResponseCode == "" {
+		return nil, fmt.Errorf("%w: %s: recusa sem código de resposta", ErrAcquirerUnavailable, acquirer.AcquirerID)
+	}
+	return &result, nil
+}
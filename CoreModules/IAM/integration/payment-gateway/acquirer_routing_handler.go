@@ -0,0 +1,88 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AcquirerRoutingHandler expõe a API HTTP do desempenho dos adquirentes e das decisões de encaminhamento
+type AcquirerRoutingHandler struct {
+	service *AcquirerRoutingService
+}
+
+// NewAcquirerRoutingHandler cria uma nova instância do AcquirerRoutingHandler
+func NewAcquirerRoutingHandler(service *AcquirerRoutingService) *AcquirerRoutingHandler {
+	return &AcquirerRoutingHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *AcquirerRoutingHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/routing/acquirers", h.ListAcquirers).Methods(http.MethodGet)
+	router.HandleFunc("/routing/performance", h.GetPerformanceReport).Methods(http.MethodGet)
+	router.HandleFunc("/routing/decisions/{transaction_id}", h.GetRoutingDecision).Methods(http.MethodGet)
+	router.HandleFunc("/routing/experiments/{experiment_id}", h.GetExperimentReport).Methods(http.MethodGet)
+}
+
+// ListAcquirers retorna os adquirentes configurados, as tarifas e os pagamentos que aceitam
+func (h *AcquirerRoutingHandler) ListAcquirers(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.Acquirers())
+}
+
+// GetPerformanceReport retorna o desempenho dos adquirentes no período (AAAA-MM-DD, datas inclusivas),
+// opcionalmente filtrado por adquirente, mercado e método de pagamento
+func (h *AcquirerRoutingHandler) GetPerformanceReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := AcquirerPerformanceFilter{
+		From:          query.Get("from"),
+		To:            query.Get("to"),
+		AcquirerID:    query.Get("acquirer_id"),
+		RegionCode:    query.Get("region_code"),
+		PaymentMethod: query.Get("payment_method"),
+	}
+
+	report, err := h.service.PerformanceReport(r.Context(), filter)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// GetRoutingDecision retorna os candidatos e as tentativas do encaminhamento de uma transação do tenant
+func (h *AcquirerRoutingHandler) GetRoutingDecision(w http.ResponseWriter, r *http.Request) {
+	decision, err := h.service.GetRoutingDecision(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["transaction_id"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, decision)
+}
+
+// GetExperimentReport retorna a comparação das variantes de uma experiência de encaminhamento
+func (h *AcquirerRoutingHandler) GetExperimentReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.ExperimentReport(r.Context(), mux.Vars(r)["experiment_id"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *AcquirerRoutingHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAcquirerPerformanceFilterInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrRoutingDecisionNotFound):
+		respondWithError(w, http.StatusNotFound, "routing_decision_not_found", err.Error())
+	case errors.Is(err, ErrRoutingExperimentNotFound):
+		respondWithError(w, http.StatusNotFound, "routing_experiment_not_found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "acquirer_routing_error", err.Error())
+	}
+}
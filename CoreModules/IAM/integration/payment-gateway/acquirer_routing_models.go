@@ -0,0 +1,356 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estratégias de encaminhamento entre adquirentes
+const (
+	RoutingStrategyLeastCost       = "least_cost"       // Menor tarifa estimada para o montante
+	RoutingStrategyHighestApproval = "highest_approval" // Maior taxa de aprovação histórica
+	RoutingStrategyPriority        = "priority"         // Ordem fixa de prioridade configurada
+)
+
+// Resultados de uma tentativa de autorização num adquirente
+const (
+	AcquirerOutcomeApproved    = "approved"
+	AcquirerOutcomeSoftDecline = "soft_decline" // Recusa transitória; a autorização segue para o próximo adquirente
+	AcquirerOutcomeHardDecline = "hard_decline" // Recusa definitiva do emissor; termina a cascata
+	AcquirerOutcomeUnavailable = "unavailable"  // Adquirente indisponível ou pedido rejeitado; segue para o próximo
+)
+
+// Valores padrão do encaminhamento entre adquirentes
+const (
+	DefaultAcquirerMaxAttempts        = 3
+	DefaultAcquirerHTTPTimeout        = 15 * time.Second
+	DefaultAcquirerStatsWindowDays    = 7
+	DefaultAcquirerStatsRefresh       = time.Minute
+	DefaultAcquirerPriorApprovalRate  = 0.85
+	DefaultAcquirerPriorWeight        = 20
+	DefaultAcquirerPerformanceMaxDays = 92
+)
+
+// DefaultSoftDeclineCodes são os códigos de resposta ISO 8583 tratados como recusas transitórias
+// (05 não honrar, 19 repetir a transação, 91 emissor indisponível, 96 falha do sistema)
+var DefaultSoftDeclineCodes = []string{"05", "19", "91", "96"}
+
+// Erros do encaminhamento entre adquirentes
+var (
+	ErrAcquirerRoutingConfigInvalid     = errors.New("configuração de encaminhamento entre adquirentes inválida")
+	ErrAcquirerUnavailable              = errors.New("adquirente indisponível")
+	ErrAcquirerRequestRejected          = errors.New("pedido de autorização rejeitado pelo adquirente")
+	ErrNoAcquirerAvailable              = errors.New("nenhum adquirente disponível para autorizar o pagamento")
+	ErrRoutingDecisionNotFound          = errors.New("decisão de encaminhamento não encontrada")
+	ErrRoutingExperimentNotFound        = errors.New("experiência de encaminhamento não encontrada")
+	ErrAcquirerPerformanceFilterInvalid = errors.New("filtro do desempenho dos adquirentes inválido")
+)
+
+// AcquirerConfig define um adquirente, os pagamentos que aceita e as suas tarifas
+// Listas vazias ou com "*" aceitam qualquer mercado, moeda ou método de pagamento
+type AcquirerConfig struct {
+	AcquirerID     string   `json:"acquirer_id"`
+	Name           string   `json:"name"`
+	APIURL         string   `json:"api_url"`
+	APIKey         string   `json:"-"`
+	RegionCodes    []string `json:"region_codes"`
+	Currencies     []string `json:"currencies"`
+	PaymentMethods []string `json:"payment_methods"`
+
+	// Priority ordena os adquirentes na estratégia priority e desempata as restantes (menor primeiro)
+	Priority int `json:"priority"`
+
+	// Tarifa do adquirente por método de pagamento; "*" aplica-se aos métodos não indicados
+	Rates map[string]CostRate `json:"rates"`
+
+	// Códigos de resposta transitórios deste adquirente, além dos códigos globais
+	SoftDeclineCodes []string `json:"soft_decline_codes,omitempty"`
+
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// supports indica se o adquirente aceita o mercado, a moeda e o método de pagamento
+func (a *AcquirerConfig) supports(regionCode, currency, paymentMethod string) bool {
+	return !a.Disabled &&
+		matchesAny(a.RegionCodes, regionCode) &&
+		matchesAny(a.Currencies, currency) &&
+		matchesAny(a.PaymentMethods, paymentMethod)
+}
+
+// matchesAny indica se a lista está vazia, contém "*" ou contém o valor
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == SegmentWildcard || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsValue indica se a lista contém o valor
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutingRule escolhe a estratégia e os adquirentes de um mercado e método de pagamento
+// Mercado ou método "*" correspondem a qualquer valor; a regra mais específica prevalece
+type RoutingRule struct {
+	RuleID        string `json:"rule_id"`
+	RegionCode    string `json:"region_code"`
+	PaymentMethod string `json:"payment_method"`
+	Strategy      string `json:"strategy"`
+
+	// Acquirers limita a regra a estes adquirentes; vazio usa todos os adquirentes elegíveis
+	Acquirers []string `json:"acquirers,omitempty"`
+
+	// MaxAttempts limita a cascata da regra; 0 usa o limite global
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
+
+// specificity indica quantas dimensões da regra são explícitas
+func (r *RoutingRule) specificity() int {
+	specificity := 0
+	if r.RegionCode != SegmentWildcard {
+		specificity += 2
+	}
+	if r.PaymentMethod != SegmentWildcard {
+		specificity++
+	}
+	return specificity
+}
+
+// matches indica se a regra se aplica ao mercado e ao método de pagamento
+func (r *RoutingRule) matches(regionCode, paymentMethod string) bool {
+	return (r.RegionCode == SegmentWildcard || r.RegionCode == regionCode) &&
+		(r.PaymentMethod == SegmentWildcard || r.PaymentMethod == paymentMethod)
+}
+
+// RoutingExperimentVariant é uma estratégia em teste numa experiência A/B
+type RoutingExperimentVariant struct {
+	Name     string `json:"name"`
+	Strategy string `json:"strategy"`
+	Weight   int    `json:"weight"` // Peso relativo na distribuição das transações
+}
+
+// RoutingExperiment distribui as transações de um mercado e método de pagamento entre estratégias
+// A variante de cada transação é determinada pelo seu identificador, pelo que as novas tentativas
+// do mesmo pagamento usam sempre a mesma variante
+type RoutingExperiment struct {
+	ExperimentID  string                     `json:"experiment_id"`
+	Description   string                     `json:"description,omitempty"`
+	RegionCode    string                     `json:"region_code"`
+	PaymentMethod string                     `json:"payment_method"`
+	Variants      []RoutingExperimentVariant `json:"variants"`
+	Disabled      bool                       `json:"disabled,omitempty"`
+}
+
+// AcquirerRoutingConfig contém os adquirentes, as regras e as experiências do encaminhamento
+type AcquirerRoutingConfig struct {
+	Acquirers   []AcquirerConfig    `json:"acquirers"`
+	Rules       []RoutingRule       `json:"rules"`
+	Experiments []RoutingExperiment `json:"experiments"`
+
+	// Estratégia usada quando nenhuma regra se aplica (padrão: least_cost)
+	DefaultStrategy string `json:"default_strategy"`
+
+	// Número máximo de adquirentes tentados na cascata de uma autorização
+	MaxAttempts int `json:"max_attempts"`
+
+	// Códigos de resposta tratados como recusas transitórias (padrão: DefaultSoftDeclineCodes)
+	SoftDeclineCodes []string `json:"soft_decline_codes"`
+
+	// Dias de histórico usados na taxa de aprovação e intervalo de atualização da cache de desempenho
+	StatsWindowDays int           `json:"stats_window_days"`
+	StatsRefresh    time.Duration `json:"stats_refresh"`
+
+	// A taxa de aprovação é suavizada com PriorWeight tentativas fictícias à taxa PriorApprovalRate,
+	// para que um adquirente com poucas tentativas não seja favorecido ou penalizado por acaso
+	PriorApprovalRate float64 `json:"prior_approval_rate"`
+	PriorWeight       int     `json:"prior_weight"`
+
+	HTTPTimeout time.Duration `json:"http_timeout"`
+
+	// Resilience sobrepõe HTTPTimeout e define as novas tentativas no mesmo adquirente
+	Resilience resilience.Policy `json:"resilience"`
+}
+
+// AcquirerAuthorizationRequest é o pedido de autorização enviado a um adquirente
+type AcquirerAuthorizationRequest struct {
+	TransactionID    string          `json:"transaction_id"`
+	TenantID         string          `json:"tenant_id"`
+	MerchantID       string          `json:"merchant_id"`
+	MerchantCategory string          `json:"merchant_category,omitempty"`
+	RegionCode       string          `json:"region_code"`
+	Amount           float64         `json:"amount"`
+	Currency         string          `json:"currency"`
+	PaymentMethod    string          `json:"payment_method"`
+	PaymentReference string          `json:"payment_reference,omitempty"`
+	CardCredential   *CardCredential `json:"card_credential,omitempty"`
+	Attempt          int             `json:"attempt"` // Posição do adquirente na cascata, a partir de 1
+}
+
+// AcquirerAuthorizationResult é a resposta do adquirente a um pedido de autorização
+type AcquirerAuthorizationResult struct {
+	Approved          bool   `json:"approved"`
+	ResponseCode      string `json:"response_code"`
+	ResponseMessage   string `json:"response_message,omitempty"`
+	ApprovalCode      string `json:"approval_code,omitempty"`
+	AcquirerReference string `json:"acquirer_reference,omitempty"`
+	SoftDecline       bool   `json:"soft_decline,omitempty"` // Indicação do adquirente de que a recusa é transitória
+}
+
+// RoutingCandidate é um adquirente elegível com os indicadores usados na ordenação
+type RoutingCandidate struct {
+	AcquirerID    string  `json:"acquirer_id"`
+	EstimatedCost float64 `json:"estimated_cost"`
+	ApprovalRate  float64 `json:"approval_rate"`
+	Priority      int     `json:"priority"`
+}
+
+// RoutingAttempt é uma tentativa de autorização num adquirente da cascata
+type RoutingAttempt struct {
+	AcquirerID   string `json:"acquirer_id"`
+	Outcome      string `json:"outcome"`
+	ResponseCode string `json:"response_code,omitempty"`
+	Error        string `json:"error,omitempty"`
+	LatencyMs    int64  `json:"latency_ms"`
+}
+
+// RoutingDecision regista como uma autorização foi encaminhada entre os adquirentes
+type RoutingDecision struct {
+	TransactionID string             `json:"transaction_id" db:"transaction_id"`
+	TenantID      string             `json:"tenant_id" db:"tenant_id"`
+	RegionCode    string             `json:"region_code" db:"region_code"`
+	PaymentMethod string             `json:"payment_method" db:"payment_method"`
+	Amount        float64            `json:"amount" db:"amount"`
+	Currency      string             `json:"currency" db:"currency"`
+	RuleID        string             `json:"rule_id,omitempty" db:"rule_id"`
+	Strategy      string             `json:"strategy" db:"strategy"`
+	ExperimentID  string             `json:"experiment_id,omitempty" db:"experiment_id"`
+	Variant       string             `json:"variant,omitempty" db:"variant"`
+	Candidates    []RoutingCandidate `json:"candidates" db:"-"`
+	Attempts      []RoutingAttempt   `json:"attempts" db:"-"`
+	AcquirerID    string             `json:"acquirer_id,omitempty" db:"acquirer_id"` // Adquirente que aprovou ou recusou em definitivo
+	Outcome       string             `json:"outcome" db:"outcome"`
+	DecidedAt     time.Time          `json:"decided_at" db:"decided_at"`
+}
+
+// AcquirerDailyStats acumula as tentativas de um adquirente num dia, mercado e método de pagamento
+type AcquirerDailyStats struct {
+	StatsDate      string  `json:"stats_date" db:"stats_date"`
+	AcquirerID     string  `json:"acquirer_id" db:"acquirer_id"`
+	RegionCode     string  `json:"region_code" db:"region_code"`
+	PaymentMethod  string  `json:"payment_method" db:"payment_method"`
+	Attempts       int64   `json:"attempts" db:"attempts"`
+	Approvals      int64   `json:"approvals" db:"approvals"`
+	SoftDeclines   int64   `json:"soft_declines" db:"soft_declines"`
+	HardDeclines   int64   `json:"hard_declines" db:"hard_declines"`
+	Errors         int64   `json:"errors" db:"errors"`
+	TotalLatencyMs int64   `json:"total_latency_ms" db:"total_latency_ms"`
+	ApprovedVolume float64 `json:"approved_volume" db:"approved_volume"`
+	EstimatedCost  float64 `json:"estimated_cost" db:"estimated_cost"` // Tarifas estimadas das autorizações aprovadas
+}
+
+// add acumula as tentativas de outro registo
+func (s *AcquirerDailyStats) add(other *AcquirerDailyStats) {
+	s.Attempts += other.Attempts
+	s.Approvals += other.Approvals
+	s.SoftDeclines += other.SoftDeclines
+	s.HardDeclines += other.HardDeclines
+	s.Errors += other.Errors
+	s.TotalLatencyMs += other.TotalLatencyMs
+	s.ApprovedVolume += other.ApprovedVolume
+	s.EstimatedCost += other.EstimatedCost
+}
+
+// RoutingExperimentDailyStats acumula as transações de uma variante de uma experiência num dia
+type RoutingExperimentDailyStats struct {
+	StatsDate      string  `json:"stats_date" db:"stats_date"`
+	ExperimentID   string  `json:"experiment_id" db:"experiment_id"`
+	Variant        string  `json:"variant" db:"variant"`
+	Transactions   int64   `json:"transactions" db:"transactions"`
+	Approvals      int64   `json:"approvals" db:"approvals"`
+	Attempts       int64   `json:"attempts" db:"attempts"`
+	ApprovedVolume float64 `json:"approved_volume" db:"approved_volume"`
+	EstimatedCost  float64 `json:"estimated_cost" db:"estimated_cost"`
+}
+
+// AcquirerPerformance resume o desempenho de um adquirente num mercado e método de pagamento
+type AcquirerPerformance struct {
+	AcquirerID      string  `json:"acquirer_id"`
+	RegionCode      string  `json:"region_code"`
+	PaymentMethod   string  `json:"payment_method"`
+	Attempts        int64   `json:"attempts"`
+	Approvals       int64   `json:"approvals"`
+	SoftDeclines    int64   `json:"soft_declines"`
+	HardDeclines    int64   `json:"hard_declines"`
+	Errors          int64   `json:"errors"`
+	ApprovalRate    float64 `json:"approval_rate"`
+	SoftDeclineRate float64 `json:"soft_decline_rate"`
+	ErrorRate       float64 `json:"error_rate"`
+	AvgLatencyMs    float64 `json:"avg_latency_ms"`
+	ApprovedVolume  float64 `json:"approved_volume"`
+	EstimatedCost   float64 `json:"estimated_cost"`
+	CostRateBps     float64 `json:"cost_rate_bps"` // Tarifas estimadas em pontos base do volume aprovado
+}
+
+// AcquirerPerformanceFilter delimita o relatório de desempenho (datas inclusivas, AAAA-MM-DD, em UTC)
+type AcquirerPerformanceFilter struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	AcquirerID    string `json:"acquirer_id,omitempty"`
+	RegionCode    string `json:"region_code,omitempty"`
+	PaymentMethod string `json:"payment_method,omitempty"`
+}
+
+// Validate verifica as datas do filtro e limita o período a DefaultAcquirerPerformanceMaxDays
+func (f *AcquirerPerformanceFilter) Validate() error {
+	from, err := time.Parse(SummaryDateLayout, f.From)
+	if err != nil {
+		return ErrAcquirerPerformanceFilterInvalid
+	}
+	to, err := time.Parse(SummaryDateLayout, f.To)
+	if err != nil || to.Before(from) || to.Sub(from) > DefaultAcquirerPerformanceMaxDays*24*time.Hour {
+		return ErrAcquirerPerformanceFilterInvalid
+	}
+	return nil
+}
+
+// AcquirerPerformanceReport é o desempenho dos adquirentes no período
+type AcquirerPerformanceReport struct {
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Performance []*AcquirerPerformance `json:"performance"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// RoutingVariantResult resume as transações de uma variante de uma experiência
+type RoutingVariantResult struct {
+	Variant            string  `json:"variant"`
+	Strategy           string  `json:"strategy"`
+	Weight             int     `json:"weight"`
+	Transactions       int64   `json:"transactions"`
+	Approvals          int64   `json:"approvals"`
+	ApprovalRate       float64 `json:"approval_rate"`
+	AttemptsPerPayment float64 `json:"attempts_per_payment"`
+	ApprovedVolume     float64 `json:"approved_volume"`
+	EstimatedCost      float64 `json:"estimated_cost"`
+	CostRateBps        float64 `json:"cost_rate_bps"`
+}
+
+// RoutingExperimentReport compara as variantes de uma experiência de encaminhamento
+type RoutingExperimentReport struct {
+	Experiment  RoutingExperiment      `json:"experiment"`
+	Variants    []RoutingVariantResult `json:"variants"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
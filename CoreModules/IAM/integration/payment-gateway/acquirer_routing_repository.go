@@ -0,0 +1,192 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresAcquirerRoutingStore implementa AcquirerRoutingStore para PostgreSQL
+type PostgresAcquirerRoutingStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAcquirerRoutingStore cria uma nova instância de PostgresAcquirerRoutingStore
+func NewPostgresAcquirerRoutingStore(db *sqlx.DB) *PostgresAcquirerRoutingStore {
+	return &PostgresAcquirerRoutingStore{db: db}
+}
+
+// dbRoutingDecision é a representação de RoutingDecision na base de dados
+type dbRoutingDecision struct {
+	RoutingDecision
+	Candidates []byte `db:"candidates"`
+	Attempts   []byte `db:"attempts"`
+}
+
+// SaveRoutingDecision grava a decisão; uma nova decisão da transação substitui a anterior
+func (r *PostgresAcquirerRoutingStore) SaveRoutingDecision(ctx context.Context, decision *RoutingDecision) error {
+	candidates, err := json.Marshal(decision.Candidates)
+	if err != nil {
+		return fmt.Errorf("falha ao codificar candidatos do encaminhamento: %w", err)
+	}
+	attempts, err := json.Marshal(decision.Attempts)
+	if err != nil {
+		return fmt.Errorf("falha ao codificar tentativas do encaminhamento: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.acquirer_routing_decisions (
+			tenant_id, transaction_id, region_code, payment_method, amount, currency,
+			rule_id, strategy, experiment_id, variant, candidates, attempts,
+			acquirer_id, outcome, decided_at
+		) VALUES (
+			:tenant_id, :transaction_id, :region_code, :payment_method, :amount, :currency,
+			:rule_id, :strategy, :experiment_id, :variant, :candidates, :attempts,
+			:acquirer_id, :outcome, :decided_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			rule_id = EXCLUDED.rule_id,
+			strategy = EXCLUDED.strategy,
+			experiment_id = EXCLUDED.experiment_id,
+			variant = EXCLUDED.variant,
+			candidates = EXCLUDED.candidates,
+			attempts = EXCLUDED.attempts,
+			acquirer_id = EXCLUDED.acquirer_id,
+			outcome = EXCLUDED.outcome,
+			decided_at = EXCLUDED.decided_at
+	`
+
+	row := &dbRoutingDecision{RoutingDecision: *decision, Candidates: candidates, Attempts: attempts}
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar decisão de encaminhamento: %w", err)
+	}
+
+	return nil
+}
+
+// GetRoutingDecision recupera a decisão de uma transação do tenant, ou nil se não existir
+func (r *PostgresAcquirerRoutingStore) GetRoutingDecision(ctx context.Context, tenantID, transactionID string) (*RoutingDecision, error) {
+	var row dbRoutingDecision
+	query := `
+		SELECT tenant_id, transaction_id, region_code, payment_method, amount, currency,
+			rule_id, strategy, experiment_id, variant, candidates, attempts,
+			acquirer_id, outcome, decided_at
+		FROM payment_gateway.acquirer_routing_decisions
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar decisão de encaminhamento: %w", err)
+	}
+
+	decision := row.RoutingDecision
+	decision.Candidates = []RoutingCandidate{}
+	decision.Attempts = []RoutingAttempt{}
+	if len(row.Candidates) > 0 {
+		if err := json.Unmarshal(row.Candidates, &decision.Candidates); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar candidatos do encaminhamento: %w", err)
+		}
+	}
+	if len(row.Attempts) > 0 {
+		if err := json.Unmarshal(row.Attempts, &decision.Attempts); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar tentativas do encaminhamento: %w", err)
+		}
+	}
+
+	return &decision, nil
+}
+
+// RecordAcquirerStats soma os contadores ao registo diário numa única instrução, sem perder
+// incrementos de instâncias concorrentes
+func (r *PostgresAcquirerRoutingStore) RecordAcquirerStats(ctx context.Context, delta *AcquirerDailyStats) error {
+	query := `
+		INSERT INTO payment_gateway.acquirer_daily_stats (
+			stats_date, acquirer_id, region_code, payment_method, attempts, approvals,
+			soft_declines, hard_declines, errors, total_latency_ms, approved_volume, estimated_cost
+		) VALUES (
+			:stats_date, :acquirer_id, :region_code, :payment_method, :attempts, :approvals,
+			:soft_declines, :hard_declines, :errors, :total_latency_ms, :approved_volume, :estimated_cost
+		)
+		ON CONFLICT (stats_date, acquirer_id, region_code, payment_method) DO UPDATE SET
+			attempts = acquirer_daily_stats.attempts + EXCLUDED.attempts,
+			approvals = acquirer_daily_stats.approvals + EXCLUDED.approvals,
+			soft_declines = acquirer_daily_stats.soft_declines + EXCLUDED.soft_declines,
+			hard_declines = acquirer_daily_stats.hard_declines + EXCLUDED.hard_declines,
+			errors = acquirer_daily_stats.errors + EXCLUDED.errors,
+			total_latency_ms = acquirer_daily_stats.total_latency_ms + EXCLUDED.total_latency_ms,
+			approved_volume = acquirer_daily_stats.approved_volume + EXCLUDED.approved_volume,
+			estimated_cost = acquirer_daily_stats.estimated_cost + EXCLUDED.estimated_cost
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, delta); err != nil {
+		return fmt.Errorf("falha ao registar desempenho do adquirente: %w", err)
+	}
+
+	return nil
+}
+
+// ListAcquirerStats lista os registos diários do intervalo, por data e adquirente
+func (r *PostgresAcquirerRoutingStore) ListAcquirerStats(ctx context.Context, from, to string) ([]*AcquirerDailyStats, error) {
+	var stats []*AcquirerDailyStats
+	query := `
+		SELECT to_char(stats_date, 'YYYY-MM-DD') AS stats_date, acquirer_id, region_code, payment_method,
+			attempts, approvals, soft_declines, hard_declines, errors, total_latency_ms,
+			approved_volume, estimated_cost
+		FROM payment_gateway.acquirer_daily_stats
+		WHERE stats_date >= $1 AND stats_date <= $2
+		ORDER BY stats_date, acquirer_id, region_code, payment_method
+	`
+	if err := r.db.SelectContext(ctx, &stats, query, from, to); err != nil {
+		return nil, fmt.Errorf("falha ao listar desempenho dos adquirentes: %w", err)
+	}
+
+	return stats, nil
+}
+
+// RecordExperimentStats soma os contadores ao registo diário da variante
+func (r *PostgresAcquirerRoutingStore) RecordExperimentStats(ctx context.Context, delta *RoutingExperimentDailyStats) error {
+	query := `
+		INSERT INTO payment_gateway.routing_experiment_daily_stats (
+			stats_date, experiment_id, variant, transactions, approvals, attempts,
+			approved_volume, estimated_cost
+		) VALUES (
+			:stats_date, :experiment_id, :variant, :transactions, :approvals, :attempts,
+			:approved_volume, :estimated_cost
+		)
+		ON CONFLICT (experiment_id, variant, stats_date) DO UPDATE SET
+			transactions = routing_experiment_daily_stats.transactions + EXCLUDED.transactions,
+			approvals = routing_experiment_daily_stats.approvals + EXCLUDED.approvals,
+			attempts = routing_experiment_daily_stats.attempts + EXCLUDED.attempts,
+			approved_volume = routing_experiment_daily_stats.approved_volume + EXCLUDED.approved_volume,
+			estimated_cost = routing_experiment_daily_stats.estimated_cost + EXCLUDED.estimated_cost
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, delta); err != nil {
+		return fmt.Errorf("falha ao registar resultado da experiência de encaminhamento: %w", err)
+	}
+
+	return nil
+}
+
+// ListExperimentStats lista os registos diários de uma experiência
+func (r *PostgresAcquirerRoutingStore) ListExperimentStats(ctx context.Context, experimentID string) ([]*RoutingExperimentDailyStats, error) {
+	var stats []*RoutingExperimentDailyStats
+	query := `
+		SELECT to_char(stats_date, 'YYYY-MM-DD') AS stats_date, experiment_id, variant,
+			transactions, approvals, attempts, approved_volume, estimated_cost
+		FROM payment_gateway.routing_experiment_daily_stats
+		WHERE experiment_id = $1
+		ORDER BY stats_date, variant
+	`
+	if err := r.db.SelectContext(ctx, &stats, query, experimentID); err != nil {
+		return nil, fmt.Errorf("falha ao listar resultados da experiência de encaminhamento: %w", err)
+	}
+
+	return stats, nil
+}
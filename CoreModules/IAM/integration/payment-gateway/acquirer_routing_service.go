@@ -0,0 +1,695 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// rankedAcquirer é um adquirente elegível com os indicadores da ordenação
+type rankedAcquirer struct {
+	acquirer    *AcquirerConfig
+	candidate   RoutingCandidate
+	costUnknown bool // Sem tarifa para o método de pagamento; fica no fim na estratégia least_cost
+}
+
+// AcquirerRoutingService escolhe o adquirente de cada autorização quando vários aceitam o mercado,
+// a moeda e o método de pagamento: ordena-os pela estratégia da regra aplicável (menor custo, maior
+// taxa de aprovação ou prioridade), tenta-os em cascata enquanto houver recusas transitórias ou
+// indisponibilidade, regista o desempenho de cada adquirente e distribui as transações das
+// experiências A/B entre as estratégias em teste
+type AcquirerRoutingService struct {
+	config      AcquirerRoutingConfig
+	acquirers   []*AcquirerConfig // Pela ordem da configuração
+	rules       []RoutingRule     // Da mais para a menos específica
+	experiments []RoutingExperiment
+	softCodes   map[string]bool
+	store       AcquirerRoutingStore
+	client      AcquirerClient
+
+	// Desempenho agregado na janela por adquirente, mercado e método, atualizado a partir do armazenamento
+	windowStats   map[string]*AcquirerDailyStats
+	statsLoadedAt time.Time
+	statsMutex    sync.RWMutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewAcquirerRoutingService cria o serviço de encaminhamento entre adquirentes
+// Sem cliente, os adquirentes são chamados por HTTP nas URLs configuradas
+func NewAcquirerRoutingService(config AcquirerRoutingConfig, store AcquirerRoutingStore, client AcquirerClient) (*AcquirerRoutingService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-acquirer-routing",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.DefaultStrategy == "" {
+		config.DefaultStrategy = RoutingStrategyLeastCost
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultAcquirerMaxAttempts
+	}
+	if config.SoftDeclineCodes == nil {
+		config.SoftDeclineCodes = DefaultSoftDeclineCodes
+	}
+	if config.StatsWindowDays <= 0 {
+		config.StatsWindowDays = DefaultAcquirerStatsWindowDays
+	}
+	if config.StatsRefresh <= 0 {
+		config.StatsRefresh = DefaultAcquirerStatsRefresh
+	}
+	if config.PriorApprovalRate <= 0 || config.PriorApprovalRate > 1 {
+		config.PriorApprovalRate = DefaultAcquirerPriorApprovalRate
+	}
+	if config.PriorWeight <= 0 {
+		config.PriorWeight = DefaultAcquirerPriorWeight
+	}
+	if err := validateAcquirerRoutingConfig(&config, client != nil); err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = NewHTTPAcquirerClient(config)
+	}
+
+	service := &AcquirerRoutingService{
+		config:          config,
+		experiments:     config.Experiments,
+		softCodes:       make(map[string]bool, len(config.SoftDeclineCodes)),
+		store:           store,
+		client:          client,
+		windowStats:     make(map[string]*AcquirerDailyStats),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+	for i := range config.Acquirers {
+		service.acquirers = append(service.acquirers, &config.Acquirers[i])
+	}
+	for _, code := range config.SoftDeclineCodes {
+		service.softCodes[code] = true
+	}
+
+	// As regras mais específicas são avaliadas primeiro
+	service.rules = append([]RoutingRule(nil), config.Rules...)
+	sort.SliceStable(service.rules, func(i, j int) bool {
+		return service.rules[i].specificity() > service.rules[j].specificity()
+	})
+
+	return service, nil
+}
+
+// SetClock substitui o relógio usado na latência das tentativas e na data do desempenho registado
+func (s *AcquirerRoutingService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// validateAcquirerRoutingConfig verifica os adquirentes, as regras e as experiências
+func validateAcquirerRoutingConfig(config *AcquirerRoutingConfig, hasClient bool) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrAcquirerRoutingConfigInvalid, fmt.Sprintf(format, args...))
+	}
+	if !isRoutingStrategy(config.DefaultStrategy) {
+		return invalid("estratégia padrão desconhecida %q", config.DefaultStrategy)
+	}
+
+	acquirers := make(map[string]bool, len(config.Acquirers))
+	for _, acquirer := range config.Acquirers {
+		if acquirer.AcquirerID == "" || acquirers[acquirer.AcquirerID] {
+			return invalid("adquirente sem identificador ou duplicado %q", acquirer.AcquirerID)
+		}
+		if !hasClient && acquirer.APIURL == "" {
+			return invalid("URL da API não configurada para o adquirente %s", acquirer.AcquirerID)
+		}
+		for _, rate := range acquirer.Rates {
+			if !rate.validate() {
+				return invalid("tarifa inválida no adquirente %s", acquirer.AcquirerID)
+			}
+		}
+		acquirers[acquirer.AcquirerID] = true
+	}
+
+	rules := make(map[string]bool, len(config.Rules))
+	for _, rule := range config.Rules {
+		key := rule.RegionCode + "\x00" + rule.PaymentMethod
+		if rule.RuleID == "" || rule.RegionCode == "" || rule.PaymentMethod == "" || rules[key] {
+			return invalid("regra incompleta ou duplicada %q", rule.RuleID)
+		}
+		if !isRoutingStrategy(rule.Strategy) || rule.MaxAttempts < 0 {
+			return invalid("estratégia ou número de tentativas inválido na regra %s", rule.RuleID)
+		}
+		for _, acquirerID := range rule.Acquirers {
+			if !acquirers[acquirerID] {
+				return invalid("a regra %s refere o adquirente desconhecido %s", rule.RuleID, acquirerID)
+			}
+		}
+		rules[key] = true
+	}
+
+	experiments := make(map[string]bool, len(config.Experiments))
+	for _, experiment := range config.Experiments {
+		if experiment.ExperimentID == "" || experiments[experiment.ExperimentID] ||
+			experiment.RegionCode == "" || experiment.PaymentMethod == "" || len(experiment.Variants) < 2 {
+			return invalid("experiência incompleta ou duplicada %q", experiment.ExperimentID)
+		}
+		variants := make(map[string]bool, len(experiment.Variants))
+		for _, variant := range experiment.Variants {
+			if variant.Name == "" || variants[variant.Name] || variant.Weight <= 0 || !isRoutingStrategy(variant.Strategy) {
+				return invalid("variante inválida %q na experiência %s", variant.Name, experiment.ExperimentID)
+			}
+			variants[variant.Name] = true
+		}
+		experiments[experiment.ExperimentID] = true
+	}
+	return nil
+}
+
+// isRoutingStrategy indica se a estratégia é conhecida
+func isRoutingStrategy(strategy string) bool {
+	switch strategy {
+	case RoutingStrategyLeastCost, RoutingStrategyHighestApproval, RoutingStrategyPriority:
+		return true
+	}
+	return false
+}
+
+// Acquirers retorna os adquirentes configurados, sem as credenciais das APIs
+func (s *AcquirerRoutingService) Acquirers() []AcquirerConfig {
+	return append([]AcquirerConfig(nil), s.config.Acquirers...)
+}
+
+// Route autoriza o pagamento nos adquirentes elegíveis, pela ordem da estratégia aplicável, e retorna a
+// decisão e a resposta do adquirente que aprovou ou recusou o pagamento
+// Recusas transitórias e adquirentes indisponíveis passam ao adquirente seguinte, até ao limite de
+// tentativas. Sem adquirente elegível retorna uma decisão nil, e a autorização do gateway mantém-se;
+// quando nenhum adquirente responde retorna ErrNoAcquirerAvailable
+func (s *AcquirerRoutingService) Route(ctx context.Context, req *PaymentRequest, credential *CardCredential) (*RoutingDecision, *AcquirerAuthorizationResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "AcquirerRoutingService.Route")
+	defer span.End()
+
+	rule := s.resolveRule(req.RegionCode, req.PaymentMethod)
+	candidates := s.eligibleAcquirers(req, rule)
+	if len(candidates) == 0 {
+		return nil, nil, nil
+	}
+
+	decision := &RoutingDecision{
+		TransactionID: req.TransactionID,
+		TenantID:      req.TenantID,
+		RegionCode:    req.RegionCode,
+		PaymentMethod: req.PaymentMethod,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Strategy:      s.config.DefaultStrategy,
+	}
+	maxAttempts := s.config.MaxAttempts
+	if rule != nil {
+		decision.RuleID = rule.RuleID
+		decision.Strategy = rule.Strategy
+		if rule.MaxAttempts > 0 {
+			maxAttempts = rule.MaxAttempts
+		}
+	}
+	if experiment, variant := s.assignExperiment(req); variant != nil {
+		decision.ExperimentID = experiment.ExperimentID
+		decision.Variant = variant.Name
+		decision.Strategy = variant.Strategy
+	}
+
+	s.rank(ctx, candidates, decision.Strategy)
+	for _, ranked := range candidates {
+		decision.Candidates = append(decision.Candidates, ranked.candidate)
+	}
+	if len(candidates) > maxAttempts {
+		candidates = candidates[:maxAttempts]
+	}
+
+	var final *AcquirerAuthorizationResult
+	var estimatedCost float64
+	decision.Outcome = AcquirerOutcomeUnavailable
+	for i, ranked := range candidates {
+		started := s.now()
+		result, err := s.client.Authorize(ctx, *ranked.acquirer, &AcquirerAuthorizationRequest{
+			TransactionID:    req.TransactionID,
+			TenantID:         req.TenantID,
+			MerchantID:       req.MerchantID,
+			MerchantCategory: req.MerchantCategory,
+			RegionCode:       req.RegionCode,
+			Amount:           req.Amount,
+			Currency:         req.Currency,
+			PaymentMethod:    req.PaymentMethod,
+			PaymentReference: req.PaymentReference,
+			CardCredential:   credential,
+			Attempt:          i + 1,
+		})
+		attempt := RoutingAttempt{
+			AcquirerID: ranked.acquirer.AcquirerID,
+			Outcome:    s.classify(ranked.acquirer, result, err),
+			LatencyMs:  s.now().Sub(started).Milliseconds(),
+		}
+		if err != nil {
+			attempt.Error = err.Error()
+		} else {
+			attempt.ResponseCode = result.ResponseCode
+		}
+		decision.Attempts = append(decision.Attempts, attempt)
+		s.recordAttempt(ctx, req, ranked, attempt)
+
+		if attempt.Outcome == AcquirerOutcomeUnavailable {
+			s.logger.WarnWithContext(ctx, "Adquirente indisponível, a tentar o seguinte",
+				"transaction_id", req.TransactionID,
+				"acquirer_id", attempt.AcquirerID,
+				"error", attempt.Error)
+			continue
+		}
+
+		final = result
+		decision.AcquirerID = attempt.AcquirerID
+		decision.Outcome = attempt.Outcome
+		if attempt.Outcome == AcquirerOutcomeApproved {
+			estimatedCost = ranked.candidate.EstimatedCost
+			break
+		}
+		if attempt.Outcome == AcquirerOutcomeHardDecline {
+			break
+		}
+		s.logger.InfoWithContext(ctx, "Recusa transitória do adquirente, a tentar o seguinte",
+			"transaction_id", req.TransactionID,
+			"acquirer_id", attempt.AcquirerID,
+			"response_code", attempt.ResponseCode)
+	}
+	decision.DecidedAt = s.now()
+
+	if err := s.store.SaveRoutingDecision(ctx, decision); err != nil {
+		// O registo da decisão não altera o resultado da autorização já obtida
+		s.logger.ErrorWithContext(ctx, "Falha ao gravar decisão de encaminhamento",
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+	}
+	if decision.ExperimentID != "" {
+		s.recordExperiment(ctx, decision, estimatedCost)
+	}
+	s.metricsRecorder.CounterInc("payment_acquirer_routing_decisions_total", map[string]string{
+		"region":         req.RegionCode,
+		"payment_method": req.PaymentMethod,
+		"strategy":       decision.Strategy,
+		"outcome":        decision.Outcome,
+	})
+
+	s.logger.InfoWithContext(ctx, "Autorização encaminhada entre adquirentes",
+		"transaction_id", req.TransactionID,
+		"strategy", decision.Strategy,
+		"experiment_id", decision.ExperimentID,
+		"variant", decision.Variant,
+		"acquirer_id", decision.AcquirerID,
+		"outcome", decision.Outcome,
+		"attempts", len(decision.Attempts))
+
+	if final == nil {
+		return decision, nil, ErrNoAcquirerAvailable
+	}
+	return decision, final, nil
+}
+
+// resolveRule retorna a regra mais específica para o mercado e método de pagamento, ou nil
+func (s *AcquirerRoutingService) resolveRule(regionCode, paymentMethod string) *RoutingRule {
+	for i := range s.rules {
+		if s.rules[i].matches(regionCode, paymentMethod) {
+			return &s.rules[i]
+		}
+	}
+	return nil
+}
+
+// eligibleAcquirers retorna os adquirentes que aceitam o pagamento, limitados aos adquirentes da regra
+func (s *AcquirerRoutingService) eligibleAcquirers(req *PaymentRequest, rule *RoutingRule) []*rankedAcquirer {
+	var candidates []*rankedAcquirer
+	for _, acquirer := range s.acquirers {
+		if !acquirer.supports(req.RegionCode, req.Currency, req.PaymentMethod) {
+			continue
+		}
+		if rule != nil && len(rule.Acquirers) > 0 && !containsValue(rule.Acquirers, acquirer.AcquirerID) {
+			continue
+		}
+		ranked := &rankedAcquirer{
+			acquirer:  acquirer,
+			candidate: RoutingCandidate{AcquirerID: acquirer.AcquirerID, Priority: acquirer.Priority},
+		}
+		if rate, ok := rateFor(acquirer.Rates, req.PaymentMethod); ok {
+			ranked.candidate.EstimatedCost = rate.Apply(req.Amount)
+		} else {
+			ranked.costUnknown = true
+		}
+		candidates = append(candidates, ranked)
+	}
+	return candidates
+}
+
+// assignExperiment retorna a experiência ativa mais específica do pagamento e a variante da transação
+func (s *AcquirerRoutingService) assignExperiment(req *PaymentRequest) (*RoutingExperiment, *RoutingExperimentVariant) {
+	var best *RoutingExperiment
+	bestSpecificity := -1
+	for i := range s.experiments {
+		experiment := &s.experiments[i]
+		rule := RoutingRule{RegionCode: experiment.RegionCode, PaymentMethod: experiment.PaymentMethod}
+		if experiment.Disabled || !rule.matches(req.RegionCode, req.PaymentMethod) {
+			continue
+		}
+		if rule.specificity() > bestSpecificity {
+			best, bestSpecificity = experiment, rule.specificity()
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+
+	total := 0
+	for _, variant := range best.Variants {
+		total += variant.Weight
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(best.ExperimentID + ":" + req.TransactionID))
+	bucket := int(hash.Sum32() % uint32(total))
+	for i := range best.Variants {
+		if bucket < best.Variants[i].Weight {
+			return best, &best.Variants[i]
+		}
+		bucket -= best.Variants[i].Weight
+	}
+	return best, &best.Variants[len(best.Variants)-1]
+}
+
+// rank ordena os candidatos pela estratégia; os empates são resolvidos pela prioridade configurada
+func (s *AcquirerRoutingService) rank(ctx context.Context, candidates []*rankedAcquirer, strategy string) {
+	stats := s.currentStats(ctx)
+	for _, ranked := range candidates {
+		ranked.candidate.ApprovalRate = s.approvalRate(stats[statsKey(ranked.acquirer.AcquirerID, "", "")])
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch strategy {
+		case RoutingStrategyLeastCost:
+			if a.costUnknown != b.costUnknown {
+				return !a.costUnknown
+			}
+			if a.candidate.EstimatedCost != b.candidate.EstimatedCost {
+				return a.candidate.EstimatedCost < b.candidate.EstimatedCost
+			}
+		case RoutingStrategyHighestApproval:
+			if a.candidate.ApprovalRate != b.candidate.ApprovalRate {
+				return a.candidate.ApprovalRate > b.candidate.ApprovalRate
+			}
+		}
+		if a.candidate.Priority != b.candidate.Priority {
+			return a.candidate.Priority < b.candidate.Priority
+		}
+		return a.acquirer.AcquirerID < b.acquirer.AcquirerID
+	})
+}
+
+// approvalRate retorna a taxa de aprovação suavizada pela taxa a priori
+func (s *AcquirerRoutingService) approvalRate(stats *AcquirerDailyStats) float64 {
+	var attempts, approvals float64
+	if stats != nil {
+		attempts, approvals = float64(stats.Attempts), float64(stats.Approvals)
+	}
+	weight := float64(s.config.PriorWeight)
+	return roundSummaryValue((approvals+s.config.PriorApprovalRate*weight)/(attempts+weight), 4)
+}
+
+// classify classifica a resposta do adquirente
+func (s *AcquirerRoutingService) classify(acquirer *AcquirerConfig, result *AcquirerAuthorizationResult, err error) string {
+	switch {
+	case err != nil:
+		return AcquirerOutcomeUnavailable
+	case result.Approved:
+		return AcquirerOutcomeApproved
+	case result.SoftDecline || s.softCodes[result.ResponseCode] || containsValue(acquirer.SoftDeclineCodes, result.ResponseCode):
+		return AcquirerOutcomeSoftDecline
+	default:
+		return AcquirerOutcomeHardDecline
+	}
+}
+
+// statsKey identifica o desempenho agregado de um adquirente, opcionalmente num mercado e método
+func statsKey(acquirerID, regionCode, paymentMethod string) string {
+	return acquirerID + "\x00" + regionCode + "\x00" + paymentMethod
+}
+
+// currentStats retorna o desempenho dos adquirentes na janela, recarregado do armazenamento quando expira
+// O desempenho de cada adquirente é agregado em todos os mercados e métodos de pagamento
+func (s *AcquirerRoutingService) currentStats(ctx context.Context) map[string]*AcquirerDailyStats {
+	s.statsMutex.RLock()
+	if s.now().Sub(s.statsLoadedAt) < s.config.StatsRefresh {
+		defer s.statsMutex.RUnlock()
+		return s.windowStats
+	}
+	s.statsMutex.RUnlock()
+
+	now := s.now().UTC()
+	from := now.AddDate(0, 0, -(s.config.StatsWindowDays - 1)).Format(SummaryDateLayout)
+	daily, err := s.store.ListAcquirerStats(ctx, from, now.Format(SummaryDateLayout))
+
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	s.statsLoadedAt = s.now()
+	if err != nil {
+		// Mantém o último desempenho conhecido até à próxima atualização
+		s.logger.WarnWithContext(ctx, "Falha ao carregar desempenho dos adquirentes", "error", err.Error())
+		return s.windowStats
+	}
+	stats := make(map[string]*AcquirerDailyStats)
+	for _, day := range daily {
+		key := statsKey(day.AcquirerID, "", "")
+		if stats[key] == nil {
+			stats[key] = &AcquirerDailyStats{AcquirerID: day.AcquirerID}
+		}
+		stats[key].add(day)
+	}
+	s.windowStats = stats
+	return stats
+}
+
+// recordAttempt regista a tentativa no desempenho do adquirente, no armazenamento e na janela em memória
+func (s *AcquirerRoutingService) recordAttempt(ctx context.Context, req *PaymentRequest, ranked *rankedAcquirer, attempt RoutingAttempt) {
+	delta := &AcquirerDailyStats{
+		StatsDate:      s.now().UTC().Format(SummaryDateLayout),
+		AcquirerID:     attempt.AcquirerID,
+		RegionCode:     req.RegionCode,
+		PaymentMethod:  req.PaymentMethod,
+		Attempts:       1,
+		TotalLatencyMs: attempt.LatencyMs,
+	}
+	switch attempt.Outcome {
+	case AcquirerOutcomeApproved:
+		delta.Approvals = 1
+		delta.ApprovedVolume = req.Amount
+		delta.EstimatedCost = ranked.candidate.EstimatedCost
+	case AcquirerOutcomeSoftDecline:
+		delta.SoftDeclines = 1
+	case AcquirerOutcomeHardDecline:
+		delta.HardDeclines = 1
+	default:
+		delta.Errors = 1
+	}
+
+	if err := s.store.RecordAcquirerStats(ctx, delta); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar desempenho do adquirente",
+			"acquirer_id", attempt.AcquirerID,
+			"error", err.Error())
+	}
+
+	s.statsMutex.Lock()
+	key := statsKey(attempt.AcquirerID, "", "")
+	if s.windowStats[key] == nil {
+		s.windowStats[key] = &AcquirerDailyStats{AcquirerID: attempt.AcquirerID}
+	}
+	s.windowStats[key].add(delta)
+	s.statsMutex.Unlock()
+
+	labels := map[string]string{
+		"acquirer_id":    attempt.AcquirerID,
+		"region":         req.RegionCode,
+		"payment_method": req.PaymentMethod,
+		"outcome":        attempt.Outcome,
+	}
+	s.metricsRecorder.CounterInc("payment_acquirer_attempts_total", labels)
+	s.metricsRecorder.HistogramObserve("payment_acquirer_latency_ms", float64(attempt.LatencyMs), labels)
+}
+
+// recordExperiment regista a transação na variante da experiência
+func (s *AcquirerRoutingService) recordExperiment(ctx context.Context, decision *RoutingDecision, estimatedCost float64) {
+	delta := &RoutingExperimentDailyStats{
+		StatsDate:    decision.DecidedAt.UTC().Format(SummaryDateLayout),
+		ExperimentID: decision.ExperimentID,
+		Variant:      decision.Variant,
+		Transactions: 1,
+		Attempts:     int64(len(decision.Attempts)),
+	}
+	if decision.Outcome == AcquirerOutcomeApproved {
+		delta.Approvals = 1
+		delta.ApprovedVolume = decision.Amount
+		delta.EstimatedCost = estimatedCost
+	}
+	if err := s.store.RecordExperimentStats(ctx, delta); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar resultado da experiência de encaminhamento",
+			"experiment_id", decision.ExperimentID,
+			"variant", decision.Variant,
+			"error", err.Error())
+	}
+}
+
+// GetRoutingDecision retorna a decisão de encaminhamento de uma transação do tenant
+func (s *AcquirerRoutingService) GetRoutingDecision(ctx context.Context, tenantID, transactionID string) (*RoutingDecision, error) {
+	if tenantID == "" || transactionID == "" {
+		return nil, ErrRoutingDecisionNotFound
+	}
+	decision, err := s.store.GetRoutingDecision(ctx, tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if decision == nil {
+		return nil, ErrRoutingDecisionNotFound
+	}
+	return decision, nil
+}
+
+// PerformanceReport agrega o desempenho dos adquirentes no período por adquirente, mercado e método
+func (s *AcquirerRoutingService) PerformanceReport(ctx context.Context, filter AcquirerPerformanceFilter) (*AcquirerPerformanceReport, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "AcquirerRoutingService.PerformanceReport")
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	daily, err := s.store.ListAcquirerStats(ctx, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*AcquirerDailyStats)
+	for _, day := range daily {
+		if (filter.AcquirerID != "" && day.AcquirerID != filter.AcquirerID) ||
+			(filter.RegionCode != "" && day.RegionCode != filter.RegionCode) ||
+			(filter.PaymentMethod != "" && day.PaymentMethod != filter.PaymentMethod) {
+			continue
+		}
+		key := statsKey(day.AcquirerID, day.RegionCode, day.PaymentMethod)
+		if groups[key] == nil {
+			groups[key] = &AcquirerDailyStats{AcquirerID: day.AcquirerID, RegionCode: day.RegionCode, PaymentMethod: day.PaymentMethod}
+		}
+		groups[key].add(day)
+	}
+
+	report := &AcquirerPerformanceReport{
+		From:        filter.From,
+		To:          filter.To,
+		Performance: make([]*AcquirerPerformance, 0, len(groups)),
+		GeneratedAt: s.now(),
+	}
+	for _, stats := range groups {
+		report.Performance = append(report.Performance, newAcquirerPerformance(stats))
+	}
+	sort.Slice(report.Performance, func(i, j int) bool {
+		a, b := report.Performance[i], report.Performance[j]
+		if a.AcquirerID != b.AcquirerID {
+			return a.AcquirerID < b.AcquirerID
+		}
+		if a.RegionCode != b.RegionCode {
+			return a.RegionCode < b.RegionCode
+		}
+		return a.PaymentMethod < b.PaymentMethod
+	})
+	return report, nil
+}
+
+// newAcquirerPerformance calcula as taxas e médias a partir dos contadores agregados
+func newAcquirerPerformance(stats *AcquirerDailyStats) *AcquirerPerformance {
+	performance := &AcquirerPerformance{
+		AcquirerID:     stats.AcquirerID,
+		RegionCode:     stats.RegionCode,
+		PaymentMethod:  stats.PaymentMethod,
+		Attempts:       stats.Attempts,
+		Approvals:      stats.Approvals,
+		SoftDeclines:   stats.SoftDeclines,
+		HardDeclines:   stats.HardDeclines,
+		Errors:         stats.Errors,
+		ApprovedVolume: roundSummaryValue(stats.ApprovedVolume, 2),
+		EstimatedCost:  roundSummaryValue(stats.EstimatedCost, 4),
+	}
+	if stats.Attempts > 0 {
+		attempts := float64(stats.Attempts)
+		performance.ApprovalRate = roundSummaryValue(float64(stats.Approvals)/attempts, 4)
+		performance.SoftDeclineRate = roundSummaryValue(float64(stats.SoftDeclines)/attempts, 4)
+		performance.ErrorRate = roundSummaryValue(float64(stats.Errors)/attempts, 4)
+		performance.AvgLatencyMs = roundSummaryValue(float64(stats.TotalLatencyMs)/attempts, 2)
+	}
+	if stats.ApprovedVolume > 0 {
+		performance.CostRateBps = roundSummaryValue(stats.EstimatedCost/stats.ApprovedVolume*10000, 2)
+	}
+	return performance
+}
+
+// ExperimentReport compara as variantes de uma experiência configurada
+func (s *AcquirerRoutingService) ExperimentReport(ctx context.Context, experimentID string) (*RoutingExperimentReport, error) {
+	var experiment *RoutingExperiment
+	for i := range s.experiments {
+		if s.experiments[i].ExperimentID == experimentID {
+			experiment = &s.experiments[i]
+		}
+	}
+	if experiment == nil {
+		return nil, ErrRoutingExperimentNotFound
+	}
+
+	daily, err := s.store.ListExperimentStats(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]*RoutingExperimentDailyStats)
+	for _, day := range daily {
+		if totals[day.Variant] == nil {
+			totals[day.Variant] = &RoutingExperimentDailyStats{}
+		}
+		total := totals[day.Variant]
+		total.Transactions += day.Transactions
+		total.Approvals += day.Approvals
+		total.Attempts += day.Attempts
+		total.ApprovedVolume += day.ApprovedVolume
+		total.EstimatedCost += day.EstimatedCost
+	}
+
+	report := &RoutingExperimentReport{Experiment: *experiment, GeneratedAt: s.now()}
+	for _, variant := range experiment.Variants {
+		result := RoutingVariantResult{Variant: variant.Name, Strategy: variant.Strategy, Weight: variant.Weight}
+		if total := totals[variant.Name]; total != nil {
+			result.Transactions = total.Transactions
+			result.Approvals = total.Approvals
+			result.ApprovedVolume = roundSummaryValue(total.ApprovedVolume, 2)
+			result.EstimatedCost = roundSummaryValue(total.EstimatedCost, 4)
+			if total.Transactions > 0 {
+				result.ApprovalRate = roundSummaryValue(float64(total.Approvals)/float64(total.Transactions), 4)
+				result.AttemptsPerPayment = roundSummaryValue(float64(total.Attempts)/float64(total.Transactions), 2)
+			}
+			if total.ApprovedVolume > 0 {
+				result.CostRateBps = roundSummaryValue(total.EstimatedCost/total.ApprovedVolume*10000, 2)
+			}
+		}
+		report.Variants = append(report.Variants, result)
+	}
+	return report, nil
+}
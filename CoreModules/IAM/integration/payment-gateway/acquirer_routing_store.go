@@ -0,0 +1,160 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// AcquirerRoutingStore define a persistência das decisões de encaminhamento e do desempenho dos adquirentes
+type AcquirerRoutingStore interface {
+	// SaveRoutingDecision grava a decisão, substituindo uma decisão anterior da mesma transação
+	SaveRoutingDecision(ctx context.Context, decision *RoutingDecision) error
+
+	// GetRoutingDecision recupera a decisão de uma transação do tenant, ou nil se não existir
+	GetRoutingDecision(ctx context.Context, tenantID, transactionID string) (*RoutingDecision, error)
+
+	// RecordAcquirerStats soma os contadores ao registo do dia, adquirente, mercado e método de pagamento
+	RecordAcquirerStats(ctx context.Context, delta *AcquirerDailyStats) error
+
+	// ListAcquirerStats lista os registos diários com data no intervalo [from, to] (AAAA-MM-DD)
+	ListAcquirerStats(ctx context.Context, from, to string) ([]*AcquirerDailyStats, error)
+
+	// RecordExperimentStats soma os contadores ao registo do dia e variante da experiência
+	RecordExperimentStats(ctx context.Context, delta *RoutingExperimentDailyStats) error
+
+	// ListExperimentStats lista os registos diários de uma experiência
+	ListExperimentStats(ctx context.Context, experimentID string) ([]*RoutingExperimentDailyStats, error)
+}
+
+// InMemoryAcquirerRoutingStore armazena as decisões e o desempenho dos adquirentes em memória
+type InMemoryAcquirerRoutingStore struct {
+	decisions   map[string]*RoutingDecision             // Por tenant e transação
+	stats       map[string]*AcquirerDailyStats          // Por dia, adquirente, mercado e método
+	experiments map[string]*RoutingExperimentDailyStats // Por experiência, variante e dia
+	mutex       sync.RWMutex
+}
+
+// NewInMemoryAcquirerRoutingStore cria um novo armazenamento em memória
+func NewInMemoryAcquirerRoutingStore() *InMemoryAcquirerRoutingStore {
+	return &InMemoryAcquirerRoutingStore{
+		decisions:   make(map[string]*RoutingDecision),
+		stats:       make(map[string]*AcquirerDailyStats),
+		experiments: make(map[string]*RoutingExperimentDailyStats),
+	}
+}
+
+// SaveRoutingDecision grava uma cópia da decisão
+func (s *InMemoryAcquirerRoutingStore) SaveRoutingDecision(ctx context.Context, decision *RoutingDecision) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.decisions[decision.TenantID+"\x00"+decision.TransactionID] = copyRoutingDecision(decision)
+	return nil
+}
+
+// GetRoutingDecision recupera uma cópia da decisão da transação
+func (s *InMemoryAcquirerRoutingStore) GetRoutingDecision(ctx context.Context, tenantID, transactionID string) (*RoutingDecision, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	decision, exists := s.decisions[tenantID+"\x00"+transactionID]
+	if !exists {
+		return nil, nil
+	}
+	return copyRoutingDecision(decision), nil
+}
+
+// RecordAcquirerStats soma os contadores ao registo diário
+func (s *InMemoryAcquirerRoutingStore) RecordAcquirerStats(ctx context.Context, delta *AcquirerDailyStats) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := delta.StatsDate + "\x00" + delta.AcquirerID + "\x00" + delta.RegionCode + "\x00" + delta.PaymentMethod
+	stats, exists := s.stats[key]
+	if !exists {
+		stats = &AcquirerDailyStats{
+			StatsDate:     delta.StatsDate,
+			AcquirerID:    delta.AcquirerID,
+			RegionCode:    delta.RegionCode,
+			PaymentMethod: delta.PaymentMethod,
+		}
+		s.stats[key] = stats
+	}
+	stats.add(delta)
+	return nil
+}
+
+// ListAcquirerStats lista cópias dos registos diários do intervalo, por data e adquirente
+func (s *InMemoryAcquirerRoutingStore) ListAcquirerStats(ctx context.Context, from, to string) ([]*AcquirerDailyStats, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*AcquirerDailyStats, 0)
+	for _, stats := range s.stats {
+		if stats.StatsDate < from || stats.StatsDate > to {
+			continue
+		}
+		copied := *stats
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StatsDate != result[j].StatsDate {
+			return result[i].StatsDate < result[j].StatsDate
+		}
+		return result[i].AcquirerID < result[j].AcquirerID
+	})
+	return result, nil
+}
+
+// RecordExperimentStats soma os contadores ao registo diário da variante
+func (s *InMemoryAcquirerRoutingStore) RecordExperimentStats(ctx context.Context, delta *RoutingExperimentDailyStats) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := delta.ExperimentID + "\x00" + delta.Variant + "\x00" + delta.StatsDate
+	stats, exists := s.experiments[key]
+	if !exists {
+		stats = &RoutingExperimentDailyStats{
+			StatsDate:    delta.StatsDate,
+			ExperimentID: delta.ExperimentID,
+			Variant:      delta.Variant,
+		}
+		s.experiments[key] = stats
+	}
+	stats.Transactions += delta.Transactions
+	stats.Approvals += delta.Approvals
+	stats.Attempts += delta.Attempts
+	stats.ApprovedVolume += delta.ApprovedVolume
+	stats.EstimatedCost += delta.EstimatedCost
+	return nil
+}
+
+// ListExperimentStats lista cópias dos registos diários da experiência
+func (s *InMemoryAcquirerRoutingStore) ListExperimentStats(ctx context.Context, experimentID string) ([]*RoutingExperimentDailyStats, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*RoutingExperimentDailyStats, 0)
+	for _, stats := range s.experiments {
+		if stats.ExperimentID == experimentID {
+			copied := *stats
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StatsDate != result[j].StatsDate {
+			return result[i].StatsDate < result[j].StatsDate
+		}
+		return result[i].Variant < result[j].Variant
+	})
+	return result, nil
+}
+
+// copyRoutingDecision copia a decisão e as suas listas
+func copyRoutingDecision(decision *RoutingDecision) *RoutingDecision {
+	copied := *decision
+	copied.Candidates = append([]RoutingCandidate(nil), decision.Candidates...)
+	copied.Attempts = append([]RoutingAttempt(nil), decision.Attempts...)
+	return &copied
+}
@@ -0,0 +1,69 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AsyncPaymentHandler expõe a submissão de pagamentos e a consulta de estado do modo assíncrono
+type AsyncPaymentHandler struct {
+	connector *BureauPaymentGatewayConnector
+	processor *AsyncPaymentProcessor
+}
+
+// NewAsyncPaymentHandler cria uma nova instância do AsyncPaymentHandler
+func NewAsyncPaymentHandler(connector *BureauPaymentGatewayConnector, processor *AsyncPaymentProcessor) *AsyncPaymentHandler {
+	return &AsyncPaymentHandler{connector: connector, processor: processor}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *AsyncPaymentHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/payments", h.SubmitPayment).Methods(http.MethodPost)
+	router.HandleFunc("/payments/{transactionId}/status", h.GetPaymentStatus).Methods(http.MethodGet)
+}
+
+// SubmitPayment trata a submissão de um pagamento; no modo assíncrono responde 202
+func (h *AsyncPaymentHandler) SubmitPayment(w http.ResponseWriter, r *http.Request) {
+	var req PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	response, err := h.connector.ProcessPayment(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCallbackURL) {
+			respondWithError(w, http.StatusBadRequest, "invalid_callback_url", err.Error())
+			return
+		}
+		respondWithError(w, http.StatusServiceUnavailable, "payment_submission_failed", err.Error())
+		return
+	}
+
+	if response.Status == TransactionStatusQueued {
+		w.Header().Set("Location", response.Metadata["status_url"].(string))
+		respondWithJSON(w, http.StatusAccepted, response)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetPaymentStatus trata a consulta do estado de um pagamento assíncrono
+func (h *AsyncPaymentHandler) GetPaymentStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.processor.GetStatus(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["transactionId"])
+	if err != nil {
+		if errors.Is(err, ErrAsyncPaymentNotFound) {
+			respondWithError(w, http.StatusNotFound, "payment_not_found", err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, status)
+}
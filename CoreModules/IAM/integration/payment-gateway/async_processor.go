@@ -0,0 +1,602 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Status de entrega de callbacks
+const (
+	CallbackStatusPending   = "pending"
+	CallbackStatusDelivered = "delivered"
+	CallbackStatusFailed    = "failed"
+)
+
+// Valores padrão do processamento assíncrono
+const (
+	DefaultAsyncWorkers           = 8
+	DefaultAsyncMarketConcurrency = 4
+	DefaultCallbackTimeout        = 10 * time.Second
+	DefaultCallbackMaxRetries     = 5
+	DefaultCallbackInitialBackoff = 2 * time.Second
+	CallbackSignatureHeader       = "X-Payment-Signature"
+	CallbackTimestampHeader       = "X-Payment-Timestamp"
+	asyncStatusPathTemplate       = "/payments/%s/status"
+)
+
+// AsyncPaymentProcessorConfig contém configurações do processamento assíncrono
+type AsyncPaymentProcessorConfig struct {
+	// Tenants que usam o modo assíncrono; vazio aplica-se a todos
+	EnabledTenants []string `json:"enabled_tenants"`
+
+	// Número de workers consumindo a fila
+	Workers int `json:"workers"`
+
+	// Pagamentos processados em simultâneo por mercado (código de região)
+	MarketConcurrency map[string]int `json:"market_concurrency"`
+
+	// Limite usado para mercados sem configuração específica
+	DefaultMarketConcurrency int `json:"default_market_concurrency"`
+
+	// Configurações de entrega de callbacks
+	CallbackTimeout        time.Duration `json:"callback_timeout"`
+	CallbackMaxRetries     int           `json:"callback_max_retries"`
+	CallbackInitialBackoff time.Duration `json:"callback_initial_backoff"`
+	CallbackSigningSecret  string        `json:"-"`
+
+	// Hosts aceites em URLs de callback, por tenant; "*.dominio" aceita subdomínios.
+	// Tenants sem entrada não podem indicar URL de callback
+	CallbackAllowedHosts map[string][]string `json:"callback_allowed_hosts"`
+
+	// CallbackResilience sobrepõe os campos de callback acima com a política partilhada
+	CallbackResilience resilience.Policy `json:"callback_resilience"`
+}
+
+// asyncWorkerKey marca contextos de execução dos workers, que processam de forma síncrona
+type asyncWorkerKey struct{}
+
+// AsyncPaymentProcessor enfileira pagamentos e processa-os com um pool de workers
+type AsyncPaymentProcessor struct {
+	config          AsyncPaymentProcessorConfig
+	queue           PaymentQueue
+	store           AsyncPaymentStatusStore
+	connector       *BureauPaymentGatewayConnector
+	httpClient      *http.Client
+	callbackPolicy  resilience.Policy
+	enabledTenants  map[string]bool
+	marketSlots     map[string]chan struct{}
+	slotsMutex      sync.Mutex
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewAsyncPaymentProcessor cria uma nova instância do processador assíncrono
+func NewAsyncPaymentProcessor(config AsyncPaymentProcessorConfig, queue PaymentQueue, store AsyncPaymentStatusStore, connector *BureauPaymentGatewayConnector) (*AsyncPaymentProcessor, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-async-processor",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.Workers <= 0 {
+		config.Workers = DefaultAsyncWorkers
+	}
+	if config.DefaultMarketConcurrency <= 0 {
+		config.DefaultMarketConcurrency = DefaultAsyncMarketConcurrency
+	}
+	if config.CallbackTimeout == 0 {
+		config.CallbackTimeout = DefaultCallbackTimeout
+	}
+	if config.CallbackMaxRetries == 0 {
+		config.CallbackMaxRetries = DefaultCallbackMaxRetries
+	}
+	if config.CallbackInitialBackoff == 0 {
+		config.CallbackInitialBackoff = DefaultCallbackInitialBackoff
+	}
+
+	enabledTenants := make(map[string]bool)
+	for _, tenantID := range config.EnabledTenants {
+		enabledTenants[tenantID] = true
+	}
+
+	callbackPolicy := resilience.DefaultPolicy().Merge(resilience.Policy{
+		Timeout:        config.CallbackTimeout,
+		MaxAttempts:    config.CallbackMaxRetries,
+		InitialBackoff: config.CallbackInitialBackoff,
+	}).Merge(config.CallbackResilience)
+
+	return &AsyncPaymentProcessor{
+		config:          config,
+		queue:           queue,
+		store:           store,
+		connector:       connector,
+		httpClient:      newCallbackHTTPClient(callbackPolicy),
+		callbackPolicy:  callbackPolicy,
+		enabledTenants:  enabledTenants,
+		marketSlots:     make(map[string]chan struct{}),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+	}, nil
+}
+
+// Enabled verifica se o pagamento deve ser processado no modo assíncrono; as vendas EFTPOS aguardam
+// sempre o desfecho no terminal e as transações com cartão armazenado o ID na rede da autorização
+func (p *AsyncPaymentProcessor) Enabled(ctx context.Context, req *PaymentRequest) bool {
+	if isAsyncWorkerContext(ctx) || req.PaymentMethod == PaymentMethodEFTPOS || req.StoredCredential != nil {
+		return false
+	}
+	return len(p.enabledTenants) == 0 || p.enabledTenants[req.TenantID]
+}
+
+// Submit enfileira o pagamento e retorna imediatamente com o status "queued"
+func (p *AsyncPaymentProcessor) Submit(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	ctx, span := p.tracer.StartSpan(ctx, "AsyncPaymentProcessor.Submit")
+	defer span.End()
+
+	if req.TransactionID == "" {
+		req.TransactionID = fmt.Sprintf("tx-%s", uuid.New().String())
+	}
+
+	if req.CallbackURL != "" {
+		if err := p.validateCallbackURL(req.TenantID, req.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reenvios da mesma transação retornam o estado existente
+	if existing, err := p.store.Get(ctx, req.TenantID, req.TransactionID); err == nil {
+		return p.queuedResponse(req, existing), nil
+	}
+
+	now := time.Now()
+	job := &AsyncPaymentJob{
+		JobID:       uuid.New().String(),
+		Request:     req,
+		SubmittedAt: now,
+	}
+
+	status := &AsyncPaymentStatus{
+		JobID:         job.JobID,
+		RequestID:     req.RequestID,
+		TransactionID: req.TransactionID,
+		TenantID:      req.TenantID,
+		RegionCode:    req.RegionCode,
+		Status:        TransactionStatusQueued,
+		CallbackURL:   req.CallbackURL,
+		SubmittedAt:   now,
+	}
+	if req.CallbackURL != "" {
+		status.CallbackStatus = CallbackStatusPending
+	}
+
+	if err := p.store.Save(ctx, status); err != nil {
+		return nil, fmt.Errorf("falha ao registrar pagamento assíncrono: %w", err)
+	}
+
+	if err := p.queue.Publish(ctx, job); err != nil {
+		p.metricsRecorder.CounterInc("payment_async_enqueue_errors", map[string]string{
+			"region": req.RegionCode,
+		})
+		return nil, err
+	}
+
+	p.metricsRecorder.CounterInc("payment_async_enqueued_total", map[string]string{
+		"tenant_id": req.TenantID,
+		"region":    req.RegionCode,
+	})
+
+	p.logger.InfoWithContext(ctx, "Pagamento enfileirado para processamento assíncrono",
+		"request_id", req.RequestID,
+		"transaction_id", req.TransactionID,
+		"job_id", job.JobID)
+
+	return p.queuedResponse(req, status), nil
+}
+
+// GetStatus retorna o estado atual de um pagamento assíncrono
+func (p *AsyncPaymentProcessor) GetStatus(ctx context.Context, tenantID, transactionID string) (*AsyncPaymentStatus, error) {
+	return p.store.Get(ctx, tenantID, transactionID)
+}
+
+// Start inicia o pool de workers que consome a fila
+func (p *AsyncPaymentProcessor) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go func(workerID int) {
+			defer p.wg.Done()
+
+			if err := p.queue.Consume(ctx, p.handleJob); err != nil {
+				p.logger.Error("Worker de pagamentos assíncronos encerrado com erro",
+					"worker_id", workerID,
+					"error", err.Error())
+			}
+		}(i)
+	}
+
+	p.logger.Info("Processador de pagamentos assíncronos iniciado", "workers", p.config.Workers)
+}
+
+// Stop encerra os workers e aguarda os pagamentos em processamento
+func (p *AsyncPaymentProcessor) Stop() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	return p.queue.Close()
+}
+
+// handleJob processa um pagamento consumido da fila respeitando o limite do mercado
+func (p *AsyncPaymentProcessor) handleJob(ctx context.Context, job *AsyncPaymentJob) error {
+	req := job.Request
+
+	status, err := p.store.Get(ctx, req.TenantID, req.TransactionID)
+	if err != nil {
+		// Pagamento publicado por outra instância sem estado local
+		status = &AsyncPaymentStatus{
+			JobID:         job.JobID,
+			RequestID:     req.RequestID,
+			TransactionID: req.TransactionID,
+			TenantID:      req.TenantID,
+			RegionCode:    req.RegionCode,
+			Status:        TransactionStatusQueued,
+			CallbackURL:   req.CallbackURL,
+			SubmittedAt:   job.SubmittedAt,
+		}
+	}
+
+	// Mensagens reentregues de pagamentos já concluídos são ignoradas
+	if status.IsFinal() {
+		return nil
+	}
+
+	release, err := p.acquireMarketSlot(ctx, req.RegionCode)
+	if err != nil {
+		return err
+	}
+
+	// A vaga do mercado cobre apenas o processamento; a entrega do callback,
+	// com as suas retentativas, decorre depois de a vaga ser libertada
+	slotHeld := true
+	releaseSlot := func() {
+		if slotHeld {
+			slotHeld = false
+			release()
+		}
+	}
+	defer releaseSlot()
+
+	ctx, span := p.tracer.StartSpan(ctx, "AsyncPaymentProcessor.handleJob")
+	defer span.End()
+
+	started := time.Now()
+	status.Status = TransactionStatusProcessing
+	status.StartedAt = &started
+
+	// Sem estado registado o pagamento não é processado; a mensagem não é
+	// confirmada e volta a ser entregue
+	if err := p.saveStatus(ctx, status); err != nil {
+		return err
+	}
+
+	p.metricsRecorder.HistogramObserve("payment_async_queue_wait_ms", float64(started.Sub(job.SubmittedAt).Milliseconds()), map[string]string{
+		"region": req.RegionCode,
+	})
+
+	response, err := p.connector.ProcessPayment(context.WithValue(ctx, asyncWorkerKey{}, true), req)
+	if err != nil {
+		response = p.connector.createErrorResponse(req, "processamento_erro", err.Error())
+	}
+
+	completed := time.Now()
+	status.Status = response.Status
+	status.Response = response
+	status.CompletedAt = &completed
+
+	// O pagamento já foi processado: a falha é registada, mas a mensagem é
+	// confirmada para não repetir o processamento
+	p.saveStatus(ctx, status)
+	releaseSlot()
+
+	p.metricsRecorder.CounterInc("payment_async_processed_total", map[string]string{
+		"region": req.RegionCode,
+		"status": response.Status,
+	})
+
+	if status.CallbackURL != "" {
+		p.deliverCallback(ctx, status)
+	}
+
+	return nil
+}
+
+// completeStepUp atualiza o estado de um pagamento assíncrono suspenso por step-up de MFA
+// e notifica o cliente do resultado final
+func (p *AsyncPaymentProcessor) completeStepUp(ctx context.Context, req *PaymentRequest, response *PaymentResponse) {
+	status, err := p.store.Get(ctx, req.TenantID, req.TransactionID)
+	if err != nil || status == nil {
+		// Pagamento processado de forma síncrona
+		return
+	}
+
+	completed := time.Now()
+	status.Status = response.Status
+	status.Response = response
+	status.CompletedAt = &completed
+	p.saveStatus(ctx, status)
+
+	p.metricsRecorder.CounterInc("payment_async_processed_total", map[string]string{
+		"region": req.RegionCode,
+		"status": response.Status,
+	})
+
+	if status.CallbackURL != "" {
+		p.deliverCallback(ctx, status)
+	}
+}
+
+// acquireMarketSlot reserva uma vaga de processamento para o mercado
+func (p *AsyncPaymentProcessor) acquireMarketSlot(ctx context.Context, market string) (func(), error) {
+	p.slotsMutex.Lock()
+	slots, ok := p.marketSlots[market]
+	if !ok {
+		limit := p.config.DefaultMarketConcurrency
+		if configured, found := p.config.MarketConcurrency[market]; found && configured > 0 {
+			limit = configured
+		}
+		slots = make(chan struct{}, limit)
+		p.marketSlots[market] = slots
+	}
+	p.slotsMutex.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliverCallback notifica o cliente da conclusão do pagamento com retentativas
+func (p *AsyncPaymentProcessor) deliverCallback(ctx context.Context, status *AsyncPaymentStatus) {
+	status.CallbackAttempts = 0
+	err := resilience.Do(ctx, p.callbackPolicy, func(ctx context.Context) error {
+		status.CallbackAttempts++
+		return p.sendCallback(ctx, status)
+	}, resilience.OnRetry(func(attempt resilience.Attempt) {
+		p.logger.WarnWithContext(ctx, "Falha ao entregar callback de pagamento",
+			"transaction_id", status.TransactionID,
+			"attempt", attempt.Number,
+			"retry_in", attempt.Delay.String(),
+			"error", attempt.Err.Error())
+	}))
+	if err == nil {
+		status.CallbackStatus = CallbackStatusDelivered
+		p.saveStatus(ctx, status)
+		p.metricsRecorder.CounterInc("payment_async_callbacks_total", map[string]string{
+			"result": CallbackStatusDelivered,
+		})
+		return
+	}
+
+	p.logger.WarnWithContext(ctx, "Falha ao entregar callback de pagamento",
+		"transaction_id", status.TransactionID,
+		"attempt", status.CallbackAttempts,
+		"error", err.Error())
+
+	status.CallbackStatus = CallbackStatusFailed
+	p.saveStatus(ctx, status)
+	p.metricsRecorder.CounterInc("payment_async_callbacks_total", map[string]string{
+		"result": CallbackStatusFailed,
+	})
+}
+
+// sendCallback envia o estado final do pagamento para a URL de callback do cliente
+func (p *AsyncPaymentProcessor) sendCallback(ctx context.Context, status *AsyncPaymentStatus) error {
+	// A URL é revalidada em cada entrega: o pagamento pode ter sido submetido
+	// noutra instância ou antes de uma alteração da lista de hosts permitidos
+	if err := p.validateCallbackURL(status.TenantID, status.CallbackURL); err != nil {
+		return resilience.Permanent(err)
+	}
+
+	payload, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar callback: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, status.CallbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("falha ao criar requisição de callback: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	// O cliente deduplica pelo job: a mesma chave em todas as tentativas
+	httpReq.Header.Set(resilience.IdempotencyKeyHeader, status.JobID)
+
+	// Assinatura HMAC permite ao cliente validar a origem do callback
+	if p.config.CallbackSigningSecret != "" {
+		timestamp := fmt.Sprintf("%d", time.Now().Unix())
+		httpReq.Header.Set(CallbackTimestampHeader, timestamp)
+		httpReq.Header.Set(CallbackSignatureHeader, signCallback(p.config.CallbackSigningSecret, timestamp, payload))
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("falha ao enviar callback: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback rejeitado com status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// saveStatus persiste o estado do pagamento, registando as falhas
+func (p *AsyncPaymentProcessor) saveStatus(ctx context.Context, status *AsyncPaymentStatus) error {
+	if err := p.store.Save(ctx, status); err != nil {
+		p.logger.ErrorWithContext(ctx, "Falha ao salvar estado do pagamento assíncrono",
+			"transaction_id", status.TransactionID,
+			"status", status.Status,
+			"callback_status", status.CallbackStatus,
+			"error", err.Error())
+		p.metricsRecorder.CounterInc("payment_async_status_save_errors", map[string]string{
+			"region": status.RegionCode,
+			"status": status.Status,
+		})
+		return fmt.Errorf("falha ao salvar estado do pagamento assíncrono: %w", err)
+	}
+	return nil
+}
+
+// validateCallbackURL exige https, sem credenciais, para um host permitido ao tenant
+// e que não seja um endereço IP interno
+func (p *AsyncPaymentProcessor) validateCallbackURL(tenantID, rawURL string) error {
+	callbackURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCallbackURL, err)
+	}
+	if callbackURL.Scheme != "https" {
+		return fmt.Errorf("%w: esquema %q não permitido", ErrInvalidCallbackURL, callbackURL.Scheme)
+	}
+	if callbackURL.User != nil {
+		return fmt.Errorf("%w: credenciais na URL não são permitidas", ErrInvalidCallbackURL)
+	}
+
+	host := strings.ToLower(callbackURL.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: host em falta", ErrInvalidCallbackURL)
+	}
+	if ip := net.ParseIP(host); ip != nil && isBlockedCallbackIP(ip) {
+		return fmt.Errorf("%w: endereço %s não permitido", ErrInvalidCallbackURL, host)
+	}
+	if !callbackHostAllowed(p.config.CallbackAllowedHosts[tenantID], host) {
+		return fmt.Errorf("%w: host %s não permitido para o tenant", ErrInvalidCallbackURL, host)
+	}
+
+	return nil
+}
+
+// callbackHostAllowed verifica o host contra a lista de hosts permitidos do tenant
+func callbackHostAllowed(allowed []string, host string) bool {
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// carrierGradeNAT é a gama partilhada 100.64.0.0/10, também não encaminhável
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isBlockedCallbackIP identifica endereços de redes internas, loopback e link-local
+func isBlockedCallbackIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		carrierGradeNAT.Contains(ip)
+}
+
+// newCallbackHTTPClient cria o cliente de callbacks, que recusa ligações a endereços
+// internos depois da resolução DNS e não segue redirecionamentos
+func newCallbackHTTPClient(policy resilience.Policy) *http.Client {
+	client := resilience.NewHTTPClient(policy)
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isBlockedCallbackIP(ip) {
+				return fmt.Errorf("%w: ligação a %s bloqueada", ErrInvalidCallbackURL, host)
+			}
+			return nil
+		},
+	}
+
+	client.Transport = &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return client
+}
+
+// queuedResponse cria a resposta imediata de uma submissão assíncrona
+func (p *AsyncPaymentProcessor) queuedResponse(req *PaymentRequest, status *AsyncPaymentStatus) *PaymentResponse {
+	if status.Response != nil {
+		return status.Response
+	}
+
+	return &PaymentResponse{
+		RequestID:         req.RequestID,
+		TransactionID:     req.TransactionID,
+		Status:            status.Status,
+		StatusCode:        "accepted",
+		StatusDescription: "Pagamento aceito para processamento assíncrono",
+		Metadata: map[string]interface{}{
+			"job_id":     status.JobID,
+			"status_url": fmt.Sprintf(asyncStatusPathTemplate, req.TransactionID),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+// signCallback calcula a assinatura HMAC-SHA256 de "<timestamp>.<payload>"
+func signCallback(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// isAsyncWorkerContext verifica se o contexto pertence a um worker assíncrono
+func isAsyncWorkerContext(ctx context.Context) bool {
+	worker, _ := ctx.Value(asyncWorkerKey{}).(bool)
+	return worker
+}
@@ -0,0 +1,214 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Status de processamento assíncrono
+const (
+	TransactionStatusQueued     = "queued"
+	TransactionStatusProcessing = "processing"
+)
+
+// Erros do processamento assíncrono
+var (
+	ErrAsyncPaymentNotFound = errors.New("pagamento assíncrono não encontrado")
+	ErrAsyncQueueClosed     = errors.New("fila de pagamentos assíncronos encerrada")
+	ErrInvalidCallbackURL   = errors.New("URL de callback inválida")
+)
+
+// AsyncPaymentJob representa um pagamento submetido para processamento assíncrono
+type AsyncPaymentJob struct {
+	JobID       string          `json:"job_id"`
+	Request     *PaymentRequest `json:"request"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+	Attempt     int             `json:"attempt"`
+}
+
+// AsyncPaymentStatus representa o estado de um pagamento assíncrono consultável pelo cliente
+type AsyncPaymentStatus struct {
+	JobID            string           `json:"job_id"`
+	RequestID        string           `json:"request_id"`
+	TransactionID    string           `json:"transaction_id"`
+	TenantID         string           `json:"tenant_id"`
+	RegionCode       string           `json:"region_code"`
+	Status           string           `json:"status"`
+	Response         *PaymentResponse `json:"response,omitempty"`
+	CallbackURL      string           `json:"callback_url,omitempty"`
+	CallbackStatus   string           `json:"callback_status,omitempty"`
+	CallbackAttempts int              `json:"callback_attempts"`
+	SubmittedAt      time.Time        `json:"submitted_at"`
+	StartedAt        *time.Time       `json:"started_at,omitempty"`
+	CompletedAt      *time.Time       `json:"completed_at,omitempty"`
+}
+
+// IsFinal indica se o pagamento já terminou o processamento
+func (s *AsyncPaymentStatus) IsFinal() bool {
+	return s.Status != TransactionStatusQueued && s.Status != TransactionStatusProcessing
+}
+
+// PaymentQueue define a fila usada para desacoplar a submissão do processamento de pagamentos
+type PaymentQueue interface {
+	// Publish publica um pagamento na fila
+	Publish(ctx context.Context, job *AsyncPaymentJob) error
+
+	// Consume entrega pagamentos ao handler até o contexto ser cancelado;
+	// a mensagem só é confirmada depois de o handler retornar
+	Consume(ctx context.Context, handler func(ctx context.Context, job *AsyncPaymentJob) error) error
+
+	// Close encerra as conexões da fila
+	Close() error
+}
+
+// AsyncPaymentStatusStore define o armazenamento do estado dos pagamentos assíncronos
+type AsyncPaymentStatusStore interface {
+	Save(ctx context.Context, status *AsyncPaymentStatus) error
+	Get(ctx context.Context, tenantID, transactionID string) (*AsyncPaymentStatus, error)
+}
+
+// KafkaPaymentQueueConfig contém configurações da fila Kafka de pagamentos
+type KafkaPaymentQueueConfig struct {
+	BootstrapServers []string `json:"bootstrap_servers"`
+	Topic            string   `json:"topic"`
+	ConsumerGroup    string   `json:"consumer_group"`
+}
+
+// KafkaPaymentQueue implementa PaymentQueue sobre Kafka
+type KafkaPaymentQueue struct {
+	writer *kafka.Writer
+	reader *kafka.Reader
+}
+
+// NewKafkaPaymentQueue cria uma nova fila de pagamentos sobre Kafka
+func NewKafkaPaymentQueue(config KafkaPaymentQueueConfig) (*KafkaPaymentQueue, error) {
+	if len(config.BootstrapServers) == 0 || config.Topic == "" {
+		return nil, fmt.Errorf("servidores Kafka e tópico são obrigatórios")
+	}
+	if config.ConsumerGroup == "" {
+		config.ConsumerGroup = "payment-gateway-async-workers"
+	}
+
+	return &KafkaPaymentQueue{
+		// Mensagens com a mesma chave (transação) vão para a mesma partição
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.BootstrapServers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: config.BootstrapServers,
+			Topic:   config.Topic,
+			GroupID: config.ConsumerGroup,
+		}),
+	}, nil
+}
+
+// Publish publica um pagamento no tópico Kafka
+func (q *KafkaPaymentQueue) Publish(ctx context.Context, job *AsyncPaymentJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar pagamento: %w", err)
+	}
+
+	err = q.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(job.Request.TransactionID),
+		Value: payload,
+		Headers: adapter.InjectKafkaHeaders(ctx, []kafka.Header{
+			{Key: "tenant_id", Value: []byte(job.Request.TenantID)},
+			{Key: "region_code", Value: []byte(job.Request.RegionCode)},
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao publicar pagamento no Kafka: %w", err)
+	}
+
+	return nil
+}
+
+// Consume lê pagamentos do tópico Kafka e confirma cada mensagem após o processamento
+// O handler recebe o contexto do span de processamento, continuação do trace do produtor
+func (q *KafkaPaymentQueue) Consume(ctx context.Context, handler func(ctx context.Context, job *AsyncPaymentJob) error) error {
+	for {
+		msg, err := q.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("falha ao ler pagamento do Kafka: %w", err)
+		}
+
+		var job AsyncPaymentJob
+		if err := json.Unmarshal(msg.Value, &job); err != nil || job.Request == nil {
+			// Mensagens inválidas são confirmadas para não bloquear a partição
+			q.reader.CommitMessages(ctx, msg)
+			continue
+		}
+
+		jobCtx, span := adapter.StartKafkaConsumerSpan(ctx, msg)
+		err = handler(jobCtx, &job)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if err != nil && ctx.Err() != nil {
+			// Encerramento durante o processamento: a mensagem será reentregue
+			return nil
+		}
+
+		if err := q.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("falha ao confirmar mensagem no Kafka: %w", err)
+		}
+	}
+}
+
+// Close encerra o produtor e o consumidor Kafka
+func (q *KafkaPaymentQueue) Close() error {
+	return errors.Join(q.writer.Close(), q.reader.Close())
+}
+
+// InMemoryAsyncPaymentStatusStore armazena o estado dos pagamentos assíncronos em memória
+type InMemoryAsyncPaymentStatusStore struct {
+	statuses map[string]*AsyncPaymentStatus
+	mutex    sync.RWMutex
+}
+
+// NewInMemoryAsyncPaymentStatusStore cria um novo armazenamento em memória
+func NewInMemoryAsyncPaymentStatusStore() *InMemoryAsyncPaymentStatusStore {
+	return &InMemoryAsyncPaymentStatusStore{
+		statuses: make(map[string]*AsyncPaymentStatus),
+	}
+}
+
+// Save grava uma cópia do estado do pagamento
+func (s *InMemoryAsyncPaymentStatusStore) Save(ctx context.Context, status *AsyncPaymentStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *status
+	s.statuses[status.TenantID+"/"+status.TransactionID] = &copied
+	return nil
+}
+
+// Get retorna o estado do pagamento da transação
+func (s *InMemoryAsyncPaymentStatusStore) Get(ctx context.Context, tenantID, transactionID string) (*AsyncPaymentStatus, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status, ok := s.statuses[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrAsyncPaymentNotFound
+	}
+	copied := *status
+	return &copied, nil
+}
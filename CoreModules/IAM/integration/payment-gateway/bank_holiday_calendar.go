@@ -0,0 +1,103 @@
+package paymentgateway
+
+import (
+	"time"
+)
+
+// bankHolidayDateLayout é o formato das datas dos calendários de feriados bancários
+const bankHolidayDateLayout = "2006-01-02"
+
+// BankHolidayCalendar define os dias sem liquidação bancária de um mercado; as datas são em UTC
+type BankHolidayCalendar struct {
+	Market        string   `json:"market"`
+	Fixed         []string `json:"fixed"`                    // Feriados anuais "MM-DD"
+	EasterOffsets []int    `json:"easter_offsets,omitempty"` // Feriados móveis em dias a partir do Domingo de Páscoa
+	Dates         []string `json:"dates,omitempty"`          // Feriados de um único ano "AAAA-MM-DD"
+}
+
+// DefaultBankHolidayCalendars retorna os feriados bancários nacionais por mercado. Na UE aplica-se o
+// calendário do TARGET2; os feriados da Reserva Federal às segundas-feiras mudam de data todos os anos
+// e são indicados nas datas adicionais do mercado
+func DefaultBankHolidayCalendars() map[string]BankHolidayCalendar {
+	calendars := []BankHolidayCalendar{
+		{Market: RegionAngola, Fixed: []string{"01-01", "02-04", "03-08", "03-23", "04-04", "05-01",
+			"09-17", "11-02", "11-11", "12-25"}, EasterOffsets: []int{-47, -2}}, // Carnaval e Sexta-feira Santa
+		{Market: RegionBrazil, Fixed: []string{"01-01", "04-21", "05-01", "09-07", "10-12", "11-02",
+			"11-15", "11-20", "12-25"}, EasterOffsets: []int{-48, -47, -2, 60}}, // Carnaval, Sexta-feira Santa e Corpus Christi
+		{Market: RegionEU, Fixed: []string{"01-01", "05-01", "12-25", "12-26"},
+			EasterOffsets: []int{-2, 1}}, // Sexta-feira Santa e Segunda-feira de Páscoa
+		{Market: RegionMozambique, Fixed: []string{"01-01", "02-03", "04-07", "05-01", "06-25", "09-07",
+			"09-25", "10-04", "12-25"}},
+		{Market: RegionUSA, Fixed: []string{"01-01", "06-19", "07-04", "11-11", "12-25"}},
+		{Market: RegionGlobal, Fixed: []string{"01-01", "12-25"}},
+	}
+
+	byMarket := make(map[string]BankHolidayCalendar, len(calendars))
+	for _, calendar := range calendars {
+		byMarket[calendar.Market] = calendar
+	}
+	return byMarket
+}
+
+// IsHoliday indica se o dia é feriado bancário no mercado
+func (c BankHolidayCalendar) IsHoliday(day time.Time) bool {
+	day = calendarDay(day)
+	if containsValue(c.Fixed, day.Format("01-02")) || containsValue(c.Dates, day.Format(bankHolidayDateLayout)) {
+		return true
+	}
+	easter := easterSunday(day.Year())
+	for _, offset := range c.EasterOffsets {
+		if easter.AddDate(0, 0, offset).Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBusinessDay indica se o dia é útil: nem fim de semana nem feriado bancário
+func (c BankHolidayCalendar) IsBusinessDay(day time.Time) bool {
+	if weekday := day.UTC().Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(day)
+}
+
+// NextBusinessDay retorna o próprio dia, quando útil, ou o dia útil seguinte
+func (c BankHolidayCalendar) NextBusinessDay(day time.Time) time.Time {
+	day = calendarDay(day)
+	for !c.IsBusinessDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// PreviousBusinessDay retorna o dia útil anterior ao dia
+func (c BankHolidayCalendar) PreviousBusinessDay(day time.Time) time.Time {
+	day = calendarDay(day).AddDate(0, 0, -1)
+	for !c.IsBusinessDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// easterSunday calcula o Domingo de Páscoa do calendário gregoriano (algoritmo de Meeus/Jones/Butcher)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// calendarDay trunca o instante ao início do dia em UTC
+func calendarDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,158 @@
+package paymentgateway
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Data base do fator de vencimento FEBRABAN; o fator reinicia em 1000 ao atingir 9999
+var boletoDueFactorBase = time.Date(1997, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+// boletoDueFactor calcula o fator de vencimento (4 dígitos) a partir da data de vencimento
+func boletoDueFactor(dueDate time.Time) (int, error) {
+	due := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(due.Sub(boletoDueFactorBase).Hours() / 24)
+	if days < 1000 {
+		return 0, fmt.Errorf("%w: data de vencimento anterior ao fator mínimo", ErrBoletoInvalidRequest)
+	}
+	if days > 9999 {
+		// Reinício do fator a partir de 22/02/2025
+		days = (days-10000)%9000 + 1000
+	}
+	return days, nil
+}
+
+// boletoCents converte o valor em centavos arredondados
+func boletoCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// boletoFreeField monta o campo livre (25 dígitos) no leiaute agência/carteira/nosso número/conta
+func boletoFreeField(agency, wallet, nossoNumero, account string) string {
+	return padDigits(agency, 4) + padDigits(wallet, 2) + padDigits(nossoNumero, 11) + padDigits(account, 7) + "0"
+}
+
+// BuildBoletoBarcode monta o código de barras de 44 dígitos segundo a especificação FEBRABAN
+func BuildBoletoBarcode(bankCode string, dueDate time.Time, amount float64, freeField string) (string, error) {
+	if len(bankCode) != 3 || !isDigits(bankCode) {
+		return "", fmt.Errorf("%w: código do banco deve ter 3 dígitos", ErrBoletoInvalidRequest)
+	}
+	if len(freeField) != 25 || !isDigits(freeField) {
+		return "", fmt.Errorf("%w: campo livre deve ter 25 dígitos", ErrBoletoInvalidRequest)
+	}
+	cents := boletoCents(amount)
+	if cents <= 0 || cents > 9999999999 {
+		return "", fmt.Errorf("%w: valor fora do intervalo do código de barras", ErrBoletoInvalidRequest)
+	}
+	factor, err := boletoDueFactor(dueDate)
+	if err != nil {
+		return "", err
+	}
+
+	// Posições 1-4 e 6-44; o dígito verificador geral ocupa a posição 5
+	withoutDV := bankCode + boletoCurrencyCode + fmt.Sprintf("%04d%010d", factor, cents) + freeField
+	dv := boletoBarcodeDV(withoutDV)
+	return withoutDV[:4] + dv + withoutDV[4:], nil
+}
+
+// BoletoLinhaDigitavel converte o código de barras na linha digitável de 47 dígitos formatada
+func BoletoLinhaDigitavel(barcode string) (string, error) {
+	if len(barcode) != 44 || !isDigits(barcode) {
+		return "", fmt.Errorf("%w: código de barras deve ter 44 dígitos", ErrBoletoInvalidRequest)
+	}
+
+	freeField := barcode[19:44]
+	field1 := barcode[0:4] + freeField[0:5]
+	field2 := freeField[5:15]
+	field3 := freeField[15:25]
+	field1 += mod10DV(field1)
+	field2 += mod10DV(field2)
+	field3 += mod10DV(field3)
+
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		field1[:5], field1[5:],
+		field2[:5], field2[5:],
+		field3[:5], field3[5:],
+		barcode[4:5],
+		barcode[5:19]), nil
+}
+
+// ParseBoletoLinhaDigitavel reconstrói o código de barras a partir da linha digitável, validando os dígitos
+func ParseBoletoLinhaDigitavel(linha string) (string, error) {
+	digits := onlyDigits(linha)
+	if len(digits) != 47 {
+		return "", fmt.Errorf("%w: linha digitável deve ter 47 dígitos", ErrBoletoInvalidRequest)
+	}
+
+	field1, field2, field3 := digits[0:10], digits[10:21], digits[21:32]
+	for _, field := range []string{field1, field2, field3} {
+		if mod10DV(field[:len(field)-1]) != field[len(field)-1:] {
+			return "", fmt.Errorf("%w: dígito verificador da linha digitável inválido", ErrBoletoInvalidRequest)
+		}
+	}
+
+	barcode := field1[0:4] + digits[32:33] + digits[33:47] + field1[4:9] + field2[0:10] + field3[0:10]
+	if boletoBarcodeDV(barcode[:4]+barcode[5:]) != barcode[4:5] {
+		return "", fmt.Errorf("%w: dígito verificador geral inválido", ErrBoletoInvalidRequest)
+	}
+	return barcode, nil
+}
+
+// boletoBarcodeDV calcula o dígito verificador geral (módulo 11, pesos 2 a 9)
+func boletoBarcodeDV(digits string) string {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv == 0 || dv == 10 || dv == 11 {
+		dv = 1
+	}
+	return fmt.Sprintf("%d", dv)
+}
+
+// mod10DV calcula o dígito verificador módulo 10 dos campos da linha digitável
+func mod10DV(digits string) string {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		product := int(digits[i]-'0') * weight
+		sum += product/10 + product%10
+		if weight == 2 {
+			weight = 1
+		} else {
+			weight = 2
+		}
+	}
+	return fmt.Sprintf("%d", (10-sum%10)%10)
+}
+
+// padDigits completa um valor numérico com zeros à esquerda, truncando os dígitos excedentes à esquerda
+func padDigits(value string, size int) string {
+	value = onlyDigits(value)
+	if len(value) > size {
+		return value[len(value)-size:]
+	}
+	return strings.Repeat("0", size-len(value)) + value
+}
+
+// onlyDigits remove todos os caracteres não numéricos
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isDigits verifica se o valor contém apenas dígitos
+func isDigits(value string) bool {
+	return value != "" && onlyDigits(value) == value
+}
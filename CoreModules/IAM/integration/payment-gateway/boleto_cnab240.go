@@ -0,0 +1,435 @@
+package paymentgateway
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Leiaute FEBRABAN CNAB 240 de cobrança (versão de arquivo 107, versão de lote 045)
+const (
+	cnab240LineLength    = 240
+	cnab240FileVersion   = "107"
+	cnab240BatchVersion  = "045"
+	cnab240DateLayout    = "02012006"
+	cnab240TimeLayout    = "150405"
+	cnab240FileRemessa   = "1"
+	cnab240FileRetorno   = "2"
+	cnab240ServiceBoleto = "01"
+)
+
+// Códigos de movimento de remessa
+const (
+	CNAB240RemessaEntry    = "01" // Entrada de títulos
+	CNAB240RemessaWriteOff = "02" // Pedido de baixa
+)
+
+// Códigos de movimento de retorno usados na conciliação
+const (
+	CNAB240RetornoEntryConfirmed = "02" // Entrada confirmada
+	CNAB240RetornoEntryRejected  = "03" // Entrada rejeitada
+	CNAB240RetornoSettled        = "06" // Liquidação
+	CNAB240RetornoWrittenOff     = "09" // Baixa
+	CNAB240RetornoSettledAfter   = "17" // Liquidação após baixa ou título não registrado
+)
+
+// CNAB240Beneficiary identifica o beneficiário (cedente) nos headers do arquivo
+type CNAB240Beneficiary struct {
+	BankCode  string
+	BankName  string
+	Document  string // CNPJ do beneficiário
+	Name      string
+	Agreement string // Convênio de cobrança
+	Agency    string
+	Account   string
+	Wallet    string
+
+	// Prazo em dias após o vencimento para o banco baixar o título automaticamente
+	WriteOffDays int
+}
+
+// CNAB240Title representa um título do arquivo, combinando os segmentos P/Q (remessa) ou T/U (retorno)
+type CNAB240Title struct {
+	MovementCode     string     `json:"movement_code"`
+	NossoNumero      string     `json:"nosso_numero"`
+	DocumentNumber   string     `json:"document_number,omitempty"`
+	DueDate          time.Time  `json:"due_date"`
+	Amount           float64    `json:"amount"`
+	PayerDocument    string     `json:"payer_document,omitempty"`
+	PayerName        string     `json:"payer_name,omitempty"`
+	Fee              float64    `json:"fee,omitempty"`
+	RejectionReasons []string   `json:"rejection_reasons,omitempty"`
+	Charges          float64    `json:"charges,omitempty"`
+	PaidAmount       float64    `json:"paid_amount,omitempty"`
+	NetAmount        float64    `json:"net_amount,omitempty"`
+	OccurrenceDate   *time.Time `json:"occurrence_date,omitempty"`
+	CreditDate       *time.Time `json:"credit_date,omitempty"`
+}
+
+// CNAB240File representa um arquivo CNAB 240 de cobrança lido
+type CNAB240File struct {
+	BankCode    string         `json:"bank_code"`
+	IsRetorno   bool           `json:"is_retorno"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Sequence    int            `json:"sequence"`
+	Titles      []CNAB240Title `json:"titles"`
+}
+
+// cnab240Line é uma linha de posições fixas preenchida com brancos
+type cnab240Line []byte
+
+func newCNAB240Line() cnab240Line {
+	return cnab240Line(strings.Repeat(" ", cnab240LineLength))
+}
+
+// alpha grava um campo alfanumérico alinhado à esquerda nas posições start-end (base 1, inclusivas)
+func (l cnab240Line) alpha(start, end int, value string) {
+	size := end - start + 1
+	value = strings.ToUpper(cnab240ASCII(value))
+	if len(value) > size {
+		value = value[:size]
+	}
+	copy(l[start-1:end], value+strings.Repeat(" ", size-len(value)))
+}
+
+// num grava um campo numérico alinhado à direita com zeros à esquerda
+func (l cnab240Line) num(start, end int, value string) {
+	copy(l[start-1:end], padDigits(value, end-start+1))
+}
+
+// amount grava um valor monetário com duas casas decimais implícitas
+func (l cnab240Line) amount(start, end int, value float64) {
+	l.num(start, end, strconv.FormatInt(boletoCents(value), 10))
+}
+
+// date grava uma data no formato DDMMAAAA, ou zeros quando ausente
+func (l cnab240Line) date(start, end int, value time.Time) {
+	if value.IsZero() {
+		l.num(start, end, "0")
+		return
+	}
+	l.num(start, end, value.Format(cnab240DateLayout))
+}
+
+// account grava agência, conta e dígitos nas posições comuns aos headers e segmentos
+func (l cnab240Line) account(offset int, b CNAB240Beneficiary) {
+	l.num(offset, offset+4, b.Agency)
+	l.num(offset+6, offset+17, b.Account)
+}
+
+// GenerateCNAB240Remessa gera o arquivo de remessa com um lote de cobrança para os boletos indicados
+// Boletos ativos são enviados como entrada de títulos e boletos baixados como pedido de baixa
+func GenerateCNAB240Remessa(beneficiary CNAB240Beneficiary, sequence int, boletos []*Boleto, now time.Time) ([]byte, error) {
+	if len(beneficiary.BankCode) != 3 || beneficiary.Document == "" {
+		return nil, fmt.Errorf("%w: beneficiário sem banco ou documento", ErrCNAB240InvalidFile)
+	}
+
+	var lines []cnab240Line
+
+	// Header de arquivo
+	header := newCNAB240Line()
+	header.num(1, 3, beneficiary.BankCode)
+	header.num(4, 7, "0")
+	header.num(8, 8, "0")
+	header.num(18, 18, "2")
+	header.num(19, 32, beneficiary.Document)
+	header.alpha(33, 52, beneficiary.Agreement)
+	header.account(53, beneficiary)
+	header.alpha(73, 102, beneficiary.Name)
+	header.alpha(103, 132, beneficiary.BankName)
+	header.num(143, 143, cnab240FileRemessa)
+	header.num(144, 151, now.Format(cnab240DateLayout))
+	header.num(152, 157, now.Format(cnab240TimeLayout))
+	header.num(158, 163, strconv.Itoa(sequence))
+	header.num(164, 166, cnab240FileVersion)
+	header.num(167, 171, "0")
+	lines = append(lines, header)
+
+	// Header de lote
+	batch := newCNAB240Line()
+	batch.num(1, 3, beneficiary.BankCode)
+	batch.num(4, 7, "1")
+	batch.num(8, 8, "1")
+	batch.alpha(9, 9, "R")
+	batch.num(10, 11, cnab240ServiceBoleto)
+	batch.num(14, 16, cnab240BatchVersion)
+	batch.num(18, 18, "2")
+	batch.num(19, 33, beneficiary.Document)
+	batch.alpha(34, 53, beneficiary.Agreement)
+	batch.account(54, beneficiary)
+	batch.alpha(74, 103, beneficiary.Name)
+	batch.num(184, 191, strconv.Itoa(sequence))
+	batch.num(192, 199, now.Format(cnab240DateLayout))
+	batch.num(200, 207, "0")
+	lines = append(lines, batch)
+
+	recordSeq := 0
+	for _, boleto := range boletos {
+		movement := CNAB240RemessaEntry
+		if boleto.Status == BoletoStatusWrittenOff {
+			movement = CNAB240RemessaWriteOff
+		}
+
+		// Segmento P: dados do título
+		recordSeq++
+		p := newCNAB240Line()
+		p.num(1, 3, beneficiary.BankCode)
+		p.num(4, 7, "1")
+		p.num(8, 8, "3")
+		p.num(9, 13, strconv.Itoa(recordSeq))
+		p.alpha(14, 14, "P")
+		p.num(16, 17, movement)
+		p.account(18, beneficiary)
+		p.alpha(38, 57, boleto.NossoNumero)
+		p.num(58, 58, beneficiary.Wallet)
+		p.num(59, 59, "1")
+		p.num(60, 60, "1")
+		p.num(61, 61, "2")
+		p.num(62, 62, "2")
+		p.alpha(63, 77, boleto.DocumentNumber)
+		p.date(78, 85, boleto.DueDate)
+		p.amount(86, 100, boleto.Amount)
+		p.num(101, 106, "0")
+		p.num(107, 108, "02")
+		p.alpha(109, 109, "N")
+		p.date(110, 117, boleto.CreatedAt)
+		if boleto.InterestPerDay > 0 {
+			p.num(118, 118, "1")
+			p.date(119, 126, boleto.DueDate.AddDate(0, 0, 1))
+			p.amount(127, 141, boleto.InterestPerDay)
+		} else {
+			p.num(118, 118, "3")
+			p.num(119, 141, "0")
+		}
+		p.num(142, 195, "0")
+		p.num(221, 221, "3")
+		p.num(222, 223, "0")
+		p.num(224, 224, "1")
+		p.num(225, 227, strconv.Itoa(beneficiary.WriteOffDays))
+		p.num(228, 229, "09")
+		p.num(230, 239, "0")
+		lines = append(lines, p)
+
+		// Segmento Q: dados do pagador
+		recordSeq++
+		q := newCNAB240Line()
+		q.num(1, 3, beneficiary.BankCode)
+		q.num(4, 7, "1")
+		q.num(8, 8, "3")
+		q.num(9, 13, strconv.Itoa(recordSeq))
+		q.alpha(14, 14, "Q")
+		q.num(16, 17, movement)
+		q.num(18, 18, cnab240DocumentType(boleto.Payer.Document))
+		q.num(19, 33, boleto.Payer.Document)
+		q.alpha(34, 73, boleto.Payer.Name)
+		q.alpha(74, 113, boleto.Payer.Address)
+		postalCode := padDigits(boleto.Payer.PostalCode, 8)
+		q.num(129, 133, postalCode[:5])
+		q.num(134, 136, postalCode[5:])
+		q.alpha(137, 151, boleto.Payer.City)
+		q.alpha(152, 153, boleto.Payer.State)
+		q.num(154, 169, "0")
+		q.num(210, 212, "0")
+		lines = append(lines, q)
+	}
+
+	// Trailer de lote: header, segmentos e trailer
+	batchTrailer := newCNAB240Line()
+	batchTrailer.num(1, 3, beneficiary.BankCode)
+	batchTrailer.num(4, 7, "1")
+	batchTrailer.num(8, 8, "5")
+	batchTrailer.num(18, 23, strconv.Itoa(recordSeq+2))
+	lines = append(lines, batchTrailer)
+
+	// Trailer de arquivo
+	fileTrailer := newCNAB240Line()
+	fileTrailer.num(1, 3, beneficiary.BankCode)
+	fileTrailer.num(4, 7, "9999")
+	fileTrailer.num(8, 8, "9")
+	fileTrailer.num(18, 23, "1")
+	fileTrailer.num(24, 29, strconv.Itoa(len(lines)+1))
+	fileTrailer.num(30, 35, "0")
+	lines = append(lines, fileTrailer)
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.Write(line)
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// ParseCNAB240 lê um arquivo CNAB 240 de cobrança (remessa ou retorno) e valida os totais dos trailers
+func ParseCNAB240(r io.Reader) (*CNAB240File, error) {
+	file := &CNAB240File{}
+	scanner := bufio.NewScanner(r)
+
+	lineNumber, batchRecords, fileRecords := 0, 0, 0
+	var current *CNAB240Title
+	sawHeader, sawTrailer := false, false
+
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		lineNumber++
+		if raw == "" {
+			continue
+		}
+		if len(raw) != cnab240LineLength {
+			return nil, fmt.Errorf("%w: linha %d com %d posições", ErrCNAB240InvalidFile, lineNumber, len(raw))
+		}
+		if sawTrailer {
+			return nil, fmt.Errorf("%w: conteúdo após o trailer de arquivo", ErrCNAB240InvalidFile)
+		}
+		fileRecords++
+
+		line := cnab240Line(raw)
+		switch line.field(8, 8) {
+		case "0":
+			file.BankCode = line.field(1, 3)
+			file.IsRetorno = line.field(143, 143) == cnab240FileRetorno
+			file.GeneratedAt, _ = time.Parse(cnab240DateLayout+cnab240TimeLayout, line.field(144, 157))
+			file.Sequence, _ = strconv.Atoi(line.field(158, 163))
+			sawHeader = true
+		case "1":
+			batchRecords = 1
+		case "3":
+			batchRecords++
+			segment := line.field(14, 14)
+			switch segment {
+			case "P", "T":
+				title := CNAB240Title{
+					MovementCode: line.field(16, 17),
+					NossoNumero:  padDigits(strings.TrimSpace(line.field(38, 57)), 11),
+				}
+				if segment == "P" {
+					title.DocumentNumber = strings.TrimSpace(line.field(63, 77))
+					title.DueDate = line.dateField(78, 85)
+					title.Amount = line.amountField(86, 100)
+				} else {
+					title.DocumentNumber = strings.TrimSpace(line.field(59, 73))
+					title.DueDate = line.dateField(74, 81)
+					title.Amount = line.amountField(82, 96)
+					title.PayerDocument = strings.TrimLeft(line.field(134, 148), "0")
+					title.PayerName = strings.TrimSpace(line.field(149, 188))
+					title.Fee = line.amountField(199, 213)
+					title.RejectionReasons = cnab240Reasons(line.field(214, 223))
+				}
+				file.Titles = append(file.Titles, title)
+				current = &file.Titles[len(file.Titles)-1]
+			case "Q":
+				if current == nil {
+					return nil, fmt.Errorf("%w: segmento Q sem segmento P na linha %d", ErrCNAB240InvalidFile, lineNumber)
+				}
+				current.PayerDocument = strings.TrimLeft(line.field(19, 33), "0")
+				current.PayerName = strings.TrimSpace(line.field(34, 73))
+			case "U":
+				if current == nil {
+					return nil, fmt.Errorf("%w: segmento U sem segmento T na linha %d", ErrCNAB240InvalidFile, lineNumber)
+				}
+				current.Charges = line.amountField(18, 32)
+				current.PaidAmount = line.amountField(78, 92)
+				current.NetAmount = line.amountField(93, 107)
+				if date := line.dateField(138, 145); !date.IsZero() {
+					current.OccurrenceDate = &date
+				}
+				if date := line.dateField(146, 153); !date.IsZero() {
+					current.CreditDate = &date
+				}
+			}
+		case "5":
+			batchRecords++
+			declared, _ := strconv.Atoi(line.field(18, 23))
+			if declared != batchRecords {
+				return nil, fmt.Errorf("%w: trailer de lote declara %d registros, lidos %d", ErrCNAB240InvalidFile, declared, batchRecords)
+			}
+			current = nil
+		case "9":
+			declared, _ := strconv.Atoi(line.field(24, 29))
+			if declared != fileRecords {
+				return nil, fmt.Errorf("%w: trailer de arquivo declara %d registros, lidos %d", ErrCNAB240InvalidFile, declared, fileRecords)
+			}
+			sawTrailer = true
+		default:
+			return nil, fmt.Errorf("%w: tipo de registro desconhecido na linha %d", ErrCNAB240InvalidFile, lineNumber)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !sawHeader || !sawTrailer {
+		return nil, fmt.Errorf("%w: header ou trailer de arquivo ausente", ErrCNAB240InvalidFile)
+	}
+
+	return file, nil
+}
+
+// field retorna o conteúdo das posições start-end (base 1, inclusivas)
+func (l cnab240Line) field(start, end int) string {
+	return string(l[start-1 : end])
+}
+
+// amountField lê um valor monetário com duas casas decimais implícitas
+func (l cnab240Line) amountField(start, end int) float64 {
+	cents, _ := strconv.ParseInt(strings.TrimSpace(l.field(start, end)), 10, 64)
+	return float64(cents) / 100
+}
+
+// dateField lê uma data DDMMAAAA; zeros ou brancos resultam em data vazia
+func (l cnab240Line) dateField(start, end int) time.Time {
+	date, err := time.Parse(cnab240DateLayout, l.field(start, end))
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// cnab240Reasons separa os motivos de ocorrência (até cinco códigos de dois caracteres)
+func cnab240Reasons(field string) []string {
+	var reasons []string
+	for i := 0; i+2 <= len(field); i += 2 {
+		if code := strings.TrimSpace(field[i : i+2]); code != "" && code != "00" {
+			reasons = append(reasons, code)
+		}
+	}
+	return reasons
+}
+
+// cnab240DocumentType retorna o tipo de inscrição: 1 para CPF, 2 para CNPJ
+func cnab240DocumentType(document string) string {
+	if len(onlyDigits(document)) == 11 {
+		return "1"
+	}
+	return "2"
+}
+
+// cnab240ASCII substitui caracteres acentuados, não aceites pelos bancos nos campos alfanuméricos
+func cnab240ASCII(value string) string {
+	replacer := strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+		"é", "e", "ê", "e", "è", "e",
+		"í", "i", "î", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ö", "o",
+		"ú", "u", "ü", "u",
+		"ç", "c",
+		"Á", "A", "À", "A", "Â", "A", "Ã", "A",
+		"É", "E", "Ê", "E",
+		"Í", "I",
+		"Ó", "O", "Ô", "O", "Õ", "O",
+		"Ú", "U", "Ü", "U",
+		"Ç", "C",
+	)
+	value = replacer.Replace(value)
+
+	var b strings.Builder
+	for _, r := range value {
+		if r < 128 {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,836 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Ator registado nos eventos de auditoria gerados pelo próprio conector
+const boletoSystemActor = "system"
+
+// BoletoConfig contém as configurações do conector de boletos junto do banco parceiro
+type BoletoConfig struct {
+	// Banco parceiro e carteira de cobrança do beneficiário
+	BankCode  string `json:"bank_code"`
+	BankName  string `json:"bank_name"`
+	Agreement string `json:"agreement"`
+	Agency    string `json:"agency"`
+	Account   string `json:"account"`
+	Wallet    string `json:"wallet"`
+
+	// Beneficiário (cedente) dos boletos
+	BeneficiaryName     string `json:"beneficiary_name"`
+	BeneficiaryDocument string `json:"beneficiary_document"`
+
+	// API de registro do banco parceiro
+	RegistrationAPIURL string        `json:"registration_api_url"`
+	APIKey             string        `json:"-"`
+	HTTPTimeout        time.Duration `json:"http_timeout"`
+
+	// Resilience sobrepõe HTTPTimeout e define as novas tentativas das chamadas ao banco
+	Resilience resilience.Policy `json:"resilience"`
+
+	// Varredura de vencimentos e baixa automática dos boletos não pagos
+	SweepInterval     time.Duration `json:"sweep_interval"`
+	WriteOffAfterDays int           `json:"write_off_after_days"`
+
+	// Prazo máximo de vencimento aceite na emissão
+	MaxDueDays int `json:"max_due_days"`
+}
+
+// boletoRegistrationResponse representa a resposta da API de registro do banco parceiro
+type boletoRegistrationResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Reason string `json:"motivo,omitempty"`
+}
+
+// boletoRejectionError representa a rejeição de um pedido pelo banco parceiro (resposta 4xx)
+type boletoRejectionError struct {
+	reason string
+}
+
+func (e *boletoRejectionError) Error() string {
+	return ErrBoletoRegistrationFailed.Error() + ": " + e.reason
+}
+
+func (e *boletoRejectionError) Unwrap() error {
+	return ErrBoletoRegistrationFailed
+}
+
+// BoletoConnector emite boletos, regista-os no banco parceiro e concilia os arquivos de retorno
+type BoletoConnector struct {
+	config     BoletoConfig
+	store      BoletoStore
+	httpClient *http.Client
+	policy     resilience.Policy
+	location   *time.Location
+	webhooks   *WebhookDeliveryService
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewBoletoConnector cria o conector de boletos
+func NewBoletoConnector(config BoletoConfig, store BoletoStore) (*BoletoConnector, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-boleto",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.HTTPTimeout <= 0 {
+		config.HTTPTimeout = DefaultBoletoHTTPTimeout
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = DefaultBoletoSweepInterval
+	}
+	if config.WriteOffAfterDays <= 0 {
+		config.WriteOffAfterDays = DefaultBoletoWriteOffAfterDays
+	}
+	if config.MaxDueDays <= 0 {
+		config.MaxDueDays = DefaultBoletoMaxDueDays
+	}
+	if len(config.BankCode) != 3 || !isDigits(config.BankCode) {
+		return nil, fmt.Errorf("código do banco parceiro deve ter 3 dígitos: %q", config.BankCode)
+	}
+	if len(onlyDigits(config.BeneficiaryDocument)) != 14 {
+		return nil, fmt.Errorf("CNPJ do beneficiário deve ter 14 dígitos: %q", config.BeneficiaryDocument)
+	}
+
+	// Vencimentos são avaliados no fuso de Brasília
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		location = time.UTC
+	}
+
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: config.HTTPTimeout}).Merge(config.Resilience)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BoletoConnector{
+		config:          config,
+		store:           store,
+		httpClient:      resilience.NewHTTPClient(policy),
+		policy:          policy,
+		location:        location,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes dos boletos liquidados e baixados
+func (c *BoletoConnector) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	c.webhooks = webhooks
+}
+
+// Start inicia a varredura periódica de registros pendentes, vencimentos e baixas
+func (c *BoletoConnector) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(c.config.SweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.Sweep(c.ctx)
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	c.logger.Info("Conector de boletos iniciado", "bank_code", c.config.BankCode)
+}
+
+// Stop interrompe a varredura periódica
+func (c *BoletoConnector) Stop() error {
+	c.cancel()
+	c.wg.Wait()
+	return nil
+}
+
+// IssueBoleto emite o boleto, calcula o código de barras e a linha digitável e regista-o no banco parceiro
+// Falhas de comunicação mantêm o boleto pendente para nova tentativa na varredura ou via remessa CNAB
+func (c *BoletoConnector) IssueBoleto(ctx context.Context, req *BoletoIssueRequest) (*Boleto, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.IssueBoleto")
+	defer span.End()
+
+	if err := c.validateIssueRequest(req); err != nil {
+		return nil, err
+	}
+
+	nossoNumero, err := c.store.NextNossoNumero(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao reservar nosso número: %w", err)
+	}
+
+	freeField := boletoFreeField(c.config.Agency, c.config.Wallet, nossoNumero, c.config.Account)
+	barcode, err := BuildBoletoBarcode(c.config.BankCode, req.DueDate, req.Amount, freeField)
+	if err != nil {
+		return nil, err
+	}
+	linha, err := BoletoLinhaDigitavel(barcode)
+	if err != nil {
+		return nil, err
+	}
+
+	payer := req.Payer
+	payer.Document = onlyDigits(payer.Document)
+	boleto := &Boleto{
+		BoletoID:       uuid.New().String(),
+		TenantID:       req.TenantID,
+		MerchantID:     req.MerchantID,
+		TransactionID:  req.TransactionID,
+		BankCode:       c.config.BankCode,
+		NossoNumero:    nossoNumero,
+		DocumentNumber: req.DocumentNumber,
+		Amount:         req.Amount,
+		DueDate:        req.DueDate,
+		Payer:          payer,
+		Instructions:   req.Instructions,
+		FinePercent:    req.FinePercent,
+		InterestPerDay: req.InterestPerDay,
+		Barcode:        barcode,
+		LinhaDigitavel: linha,
+		Status:         BoletoStatusPendingRegistration,
+		CreatedAt:      c.now(),
+		UpdatedAt:      c.now(),
+	}
+
+	if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+		return nil, fmt.Errorf("falha ao gravar boleto: %w", err)
+	}
+	c.recordAudit(ctx, boleto, BoletoEventIssued, boletoSystemActor, map[string]interface{}{
+		"transaction_id": boleto.TransactionID,
+		"due_date":       boleto.DueDate.Format("2006-01-02"),
+	})
+
+	// Rejeições ficam no estado do boleto retornado; indisponibilidade do banco mantém-no pendente
+	err = c.register(ctx, boleto)
+	if err != nil && !errors.Is(err, ErrBoletoBankUnavailable) && !errors.Is(err, ErrBoletoRegistrationFailed) {
+		return nil, err
+	}
+
+	c.logger.InfoWithContext(ctx, "Boleto emitido",
+		"boleto_id", boleto.BoletoID,
+		"tenant_id", boleto.TenantID,
+		"nosso_numero", boleto.NossoNumero,
+		"status", string(boleto.Status))
+
+	return boleto, nil
+}
+
+// IssueForPayment emite o boleto de um pagamento aprovado pelo gateway; o pagamento fica pendente
+// até à liquidação comunicada no arquivo de retorno
+func (c *BoletoConnector) IssueForPayment(ctx context.Context, req *PaymentRequest) (*Boleto, error) {
+	if req.Boleto == nil {
+		return nil, fmt.Errorf("%w: dados do boleto ausentes", ErrBoletoInvalidRequest)
+	}
+	if !strings.EqualFold(req.Currency, "BRL") {
+		return nil, fmt.Errorf("%w: boletos são emitidos apenas em BRL", ErrBoletoInvalidRequest)
+	}
+
+	return c.IssueBoleto(ctx, &BoletoIssueRequest{
+		TenantID:       req.TenantID,
+		MerchantID:     req.MerchantID,
+		TransactionID:  req.TransactionID,
+		Amount:         req.Amount,
+		DueDate:        req.Boleto.DueDate,
+		Payer:          req.Boleto.Payer,
+		DocumentNumber: req.Boleto.DocumentNumber,
+		Instructions:   req.Boleto.Instructions,
+		FinePercent:    req.Boleto.FinePercent,
+		InterestPerDay: req.Boleto.InterestPerDay,
+	})
+}
+
+// GetBoleto retorna o boleto do tenant
+func (c *BoletoConnector) GetBoleto(ctx context.Context, tenantID, boletoID string) (*Boleto, error) {
+	return c.store.GetBoleto(ctx, tenantID, boletoID)
+}
+
+// RegisterBoleto tenta novamente o registro de um boleto pendente ou rejeitado
+func (c *BoletoConnector) RegisterBoleto(ctx context.Context, tenantID, boletoID string) (*Boleto, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.RegisterBoleto")
+	defer span.End()
+
+	boleto, err := c.store.GetBoleto(ctx, tenantID, boletoID)
+	if err != nil {
+		return nil, err
+	}
+	if boleto.Status != BoletoStatusPendingRegistration && boleto.Status != BoletoStatusRegistrationFailed {
+		return nil, ErrBoletoInvalidTransition
+	}
+
+	if err := c.register(ctx, boleto); err != nil {
+		return boleto, err
+	}
+	return boleto, nil
+}
+
+// WriteOffBoleto baixa o boleto, pedindo a baixa ao banco parceiro quando já registrado
+func (c *BoletoConnector) WriteOffBoleto(ctx context.Context, tenantID, boletoID, reason, actor string) (*Boleto, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.WriteOffBoleto")
+	defer span.End()
+
+	boleto, err := c.store.GetBoleto(ctx, tenantID, boletoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.writeOff(ctx, boleto, reason, actor); err != nil {
+		return nil, err
+	}
+	return boleto, nil
+}
+
+// GenerateRemessa gera o arquivo CNAB 240 de remessa com os boletos ainda não registrados via API
+func (c *BoletoConnector) GenerateRemessa(ctx context.Context) ([]byte, int, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.GenerateRemessa")
+	defer span.End()
+
+	pending, err := c.store.ListBoletosByStatus(ctx, BoletoStatusPendingRegistration)
+	if err != nil {
+		return nil, 0, err
+	}
+	sequence, err := c.store.NextRemessaSequence(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("falha ao reservar sequência de remessa: %w", err)
+	}
+
+	file, err := GenerateCNAB240Remessa(c.beneficiary(), sequence, pending, c.now().In(c.location))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.logger.InfoWithContext(ctx, "Remessa CNAB 240 gerada",
+		"sequence", sequence,
+		"titles", len(pending))
+	return file, sequence, nil
+}
+
+// ReconcileRetorno aplica um arquivo de retorno CNAB 240 aos boletos: confirmações e rejeições
+// de entrada, liquidações e baixas; valores pagos abaixo do nominal e títulos desconhecidos
+// são reportados para análise
+func (c *BoletoConnector) ReconcileRetorno(ctx context.Context, r io.Reader, actor string) (*BoletoReconciliationResult, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.ReconcileRetorno")
+	defer span.End()
+
+	file, err := ParseCNAB240(r)
+	if err != nil {
+		return nil, err
+	}
+	if !file.IsRetorno {
+		return nil, fmt.Errorf("%w: o arquivo não é de retorno", ErrCNAB240InvalidFile)
+	}
+	if file.BankCode != c.config.BankCode {
+		return nil, fmt.Errorf("%w: arquivo do banco %s, esperado %s", ErrCNAB240InvalidFile, file.BankCode, c.config.BankCode)
+	}
+
+	result := &BoletoReconciliationResult{FileSequence: file.Sequence, Records: len(file.Titles)}
+	for i := range file.Titles {
+		title := &file.Titles[i]
+		boleto, err := c.store.GetBoletoByNossoNumero(ctx, title.NossoNumero)
+		if errors.Is(err, ErrBoletoNotFound) {
+			result.Unknown = append(result.Unknown, BoletoReconciliationIssue{
+				NossoNumero:  title.NossoNumero,
+				MovementCode: title.MovementCode,
+				PaidAmount:   title.PaidAmount,
+				Reason:       "nosso número sem boleto emitido pelo gateway",
+			})
+			c.logger.WarnWithContext(ctx, "Retorno CNAB 240 com título desconhecido",
+				"nosso_numero", title.NossoNumero,
+				"movement_code", title.MovementCode)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.applyRetorno(ctx, boleto, title, actor, result); err != nil {
+			return nil, err
+		}
+	}
+
+	c.metricsRecorder.CounterInc("payment_boleto_retorno_files_total", map[string]string{
+		"bank_code":   c.config.BankCode,
+		"divergences": fmt.Sprintf("%t", len(result.Divergences) > 0 || len(result.Unknown) > 0),
+	})
+	c.logger.InfoWithContext(ctx, "Retorno CNAB 240 conciliado",
+		"sequence", file.Sequence,
+		"records", result.Records,
+		"paid", result.Paid,
+		"divergences", len(result.Divergences),
+		"unknown", len(result.Unknown))
+
+	return result, nil
+}
+
+// AuditEvents retorna os eventos de auditoria do tenant no período para o reporte ao BACEN
+func (c *BoletoConnector) AuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*BoletoAuditEvent, error) {
+	return c.store.ListAuditEvents(ctx, tenantID, from, to)
+}
+
+// Sweep tenta registrar boletos pendentes, marca como vencidos os boletos não pagos e baixa
+// os vencidos há mais do que o prazo configurado
+func (c *BoletoConnector) Sweep(ctx context.Context) {
+	ctx, span := c.tracer.StartSpan(ctx, "BoletoConnector.Sweep")
+	defer span.End()
+
+	if pending, err := c.store.ListBoletosByStatus(ctx, BoletoStatusPendingRegistration); err == nil {
+		for _, boleto := range pending {
+			if err := c.register(ctx, boleto); err != nil && errors.Is(err, ErrBoletoBankUnavailable) {
+				// Banco indisponível: as restantes ficam para a próxima varredura
+				break
+			}
+		}
+	}
+
+	today := c.today()
+	if registered, err := c.store.ListBoletosByStatus(ctx, BoletoStatusRegistered); err == nil {
+		for _, boleto := range registered {
+			if !c.dueDay(boleto).Before(today) {
+				break
+			}
+			boleto.Status = BoletoStatusExpired
+			boleto.UpdatedAt = c.now()
+			if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+				c.logger.WarnWithContext(ctx, "Falha ao marcar boleto como vencido",
+					"boleto_id", boleto.BoletoID,
+					"error", err.Error())
+				continue
+			}
+			c.recordAudit(ctx, boleto, BoletoEventExpired, boletoSystemActor, nil)
+		}
+	}
+
+	if expired, err := c.store.ListBoletosByStatus(ctx, BoletoStatusExpired); err == nil {
+		for _, boleto := range expired {
+			if !c.dueDay(boleto).AddDate(0, 0, c.config.WriteOffAfterDays).Before(today) {
+				break
+			}
+			reason := fmt.Sprintf("baixa automática após %d dias do vencimento", c.config.WriteOffAfterDays)
+			if err := c.writeOff(ctx, boleto, reason, boletoSystemActor); err != nil {
+				c.logger.WarnWithContext(ctx, "Falha na baixa automática do boleto",
+					"boleto_id", boleto.BoletoID,
+					"error", err.Error())
+			}
+		}
+	}
+}
+
+// register envia o boleto à API de registro do banco parceiro e grava o resultado
+func (c *BoletoConnector) register(ctx context.Context, boleto *Boleto) error {
+	var resp boletoRegistrationResponse
+	err := c.sendJSON(ctx, http.MethodPost, c.config.RegistrationAPIURL+"/v1/boletos", boleto.BoletoID, c.registrationPayload(boleto), &resp)
+	var rejection *boletoRejectionError
+	if errors.As(err, &rejection) {
+		return c.rejectRegistration(ctx, boleto, rejection.reason)
+	}
+	if err != nil {
+		c.metricsRecorder.CounterInc("payment_boleto_registrations_total", map[string]string{
+			"bank_code": c.config.BankCode,
+			"result":    "unavailable",
+		})
+		c.logger.WarnWithContext(ctx, "Falha de comunicação no registro do boleto, nova tentativa na varredura",
+			"boleto_id", boleto.BoletoID,
+			"error", err.Error())
+		return err
+	}
+
+	if !strings.EqualFold(resp.Status, "REGISTRADO") {
+		return c.rejectRegistration(ctx, boleto, resp.Reason)
+	}
+
+	boleto.Status = BoletoStatusRegistered
+	boleto.BankReference = resp.ID
+	boleto.FailureReason = ""
+	boleto.UpdatedAt = c.now()
+	if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+		return fmt.Errorf("falha ao gravar registro do boleto: %w", err)
+	}
+
+	c.metricsRecorder.CounterInc("payment_boleto_registrations_total", map[string]string{
+		"bank_code": c.config.BankCode,
+		"result":    "registered",
+	})
+	c.recordAudit(ctx, boleto, BoletoEventRegistered, boletoSystemActor, map[string]interface{}{
+		"bank_reference": boleto.BankReference,
+		"channel":        "api",
+	})
+	return nil
+}
+
+// rejectRegistration marca o boleto como rejeitado pelo banco parceiro
+func (c *BoletoConnector) rejectRegistration(ctx context.Context, boleto *Boleto, reason string) error {
+	boleto.Status = BoletoStatusRegistrationFailed
+	boleto.FailureReason = reason
+	boleto.UpdatedAt = c.now()
+	if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+		return fmt.Errorf("falha ao gravar rejeição do boleto: %w", err)
+	}
+
+	c.metricsRecorder.CounterInc("payment_boleto_registrations_total", map[string]string{
+		"bank_code": c.config.BankCode,
+		"result":    "rejected",
+	})
+	c.recordAudit(ctx, boleto, BoletoEventRegistrationFailed, boletoSystemActor, map[string]interface{}{
+		"reason": reason,
+	})
+	return fmt.Errorf("%w: %s", ErrBoletoRegistrationFailed, reason)
+}
+
+// writeOff baixa o boleto; boletos registrados ou vencidos exigem a baixa no banco parceiro
+func (c *BoletoConnector) writeOff(ctx context.Context, boleto *Boleto, reason, actor string) error {
+	switch boleto.Status {
+	case BoletoStatusRegistered, BoletoStatusExpired:
+		endpoint := c.config.RegistrationAPIURL + "/v1/boletos/" + url.PathEscape(boleto.NossoNumero) + "/baixa"
+		if err := c.sendJSON(ctx, http.MethodPost, endpoint, boleto.BoletoID+"-baixa", map[string]string{"motivo": reason}, nil); err != nil {
+			return fmt.Errorf("falha ao pedir baixa ao banco parceiro: %w", err)
+		}
+	case BoletoStatusPendingRegistration, BoletoStatusRegistrationFailed:
+		// Sem registro no banco: a baixa é apenas local
+	default:
+		return ErrBoletoInvalidTransition
+	}
+
+	now := c.now()
+	boleto.Status = BoletoStatusWrittenOff
+	boleto.WriteOffReason = reason
+	boleto.WrittenOffAt = &now
+	boleto.UpdatedAt = now
+	if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+		return fmt.Errorf("falha ao gravar baixa do boleto: %w", err)
+	}
+
+	c.recordAudit(ctx, boleto, BoletoEventWrittenOff, actor, map[string]interface{}{
+		"reason": reason,
+	})
+	c.notify(ctx, boleto, WebhookEventTransactionFailed, reason)
+	return nil
+}
+
+// applyRetorno aplica o movimento de um título do arquivo de retorno ao boleto
+func (c *BoletoConnector) applyRetorno(ctx context.Context, boleto *Boleto, title *CNAB240Title, actor string, result *BoletoReconciliationResult) error {
+	now := c.now()
+	var events []string
+	details := map[string]interface{}{
+		"movement_code":   title.MovementCode,
+		"retorno_channel": "cnab240",
+	}
+
+	switch title.MovementCode {
+	case CNAB240RetornoEntryConfirmed:
+		if boleto.Status != BoletoStatusPendingRegistration && boleto.Status != BoletoStatusRegistrationFailed {
+			result.Ignored++
+			return nil
+		}
+		boleto.Status = BoletoStatusRegistered
+		boleto.FailureReason = ""
+		result.Registered++
+		events = append(events, BoletoEventRegistered)
+
+	case CNAB240RetornoEntryRejected:
+		if boleto.Status != BoletoStatusPendingRegistration {
+			result.Ignored++
+			return nil
+		}
+		boleto.Status = BoletoStatusRegistrationFailed
+		boleto.FailureReason = "motivos CNAB: " + strings.Join(title.RejectionReasons, ",")
+		details["reasons"] = title.RejectionReasons
+		result.Rejected++
+		events = append(events, BoletoEventRegistrationFailed)
+
+	case CNAB240RetornoSettled, CNAB240RetornoSettledAfter:
+		if boleto.Status == BoletoStatusPaid {
+			result.Ignored++
+			return nil
+		}
+		paidAt := now
+		if title.OccurrenceDate != nil {
+			paidAt = *title.OccurrenceDate
+		}
+		boleto.Status = BoletoStatusPaid
+		boleto.PaidAmount = title.PaidAmount
+		boleto.PaidAt = &paidAt
+		boleto.CreditDate = title.CreditDate
+		boleto.BankFee = title.Fee
+		details["paid_amount"] = title.PaidAmount
+		details["charges"] = title.Charges
+		details["fee"] = title.Fee
+		result.Paid++
+
+		if boletoCents(title.PaidAmount) < boletoCents(boleto.Amount) {
+			result.Divergences = append(result.Divergences, BoletoReconciliationIssue{
+				NossoNumero:    boleto.NossoNumero,
+				MovementCode:   title.MovementCode,
+				ExpectedAmount: boleto.Amount,
+				PaidAmount:     title.PaidAmount,
+				Reason:         "valor pago inferior ao valor nominal",
+			})
+			events = append(events, BoletoEventAmountDivergence)
+		}
+		events = append(events, BoletoEventPaid)
+
+		c.metricsRecorder.CounterInc("payment_boletos_paid_total", map[string]string{
+			"bank_code": c.config.BankCode,
+		})
+
+	case CNAB240RetornoWrittenOff:
+		if boleto.Status.IsFinal() {
+			result.Ignored++
+			return nil
+		}
+		boleto.Status = BoletoStatusWrittenOff
+		boleto.WriteOffReason = "baixa comunicada pelo banco no retorno"
+		boleto.WrittenOffAt = &now
+		result.WrittenOff++
+		events = append(events, BoletoEventWrittenOff)
+
+	default:
+		result.Ignored++
+		return nil
+	}
+
+	boleto.UpdatedAt = now
+	if err := c.store.SaveBoleto(ctx, boleto); err != nil {
+		return err
+	}
+	for _, eventType := range events {
+		c.recordAudit(ctx, boleto, eventType, actor, details)
+	}
+
+	switch boleto.Status {
+	case BoletoStatusPaid:
+		c.notify(ctx, boleto, WebhookEventTransactionCompleted, "")
+	case BoletoStatusWrittenOff:
+		c.notify(ctx, boleto, WebhookEventTransactionFailed, boleto.WriteOffReason)
+	}
+	return nil
+}
+
+// notify agenda a notificação do comerciante sobre a liquidação ou a baixa do boleto
+func (c *BoletoConnector) notify(ctx context.Context, boleto *Boleto, eventType, reason string) {
+	if c.webhooks == nil || boleto.TransactionID == "" {
+		return
+	}
+
+	status, amount := TransactionStatusApproved, boleto.Amount
+	if eventType == WebhookEventTransactionFailed {
+		status = TransactionStatusDenied
+	} else if boleto.PaidAmount > 0 {
+		amount = boleto.PaidAmount
+	}
+	if _, err := c.webhooks.Enqueue(ctx, WebhookEvent{
+		EventType:     eventType,
+		TenantID:      boleto.TenantID,
+		MerchantID:    boleto.MerchantID,
+		TransactionID: boleto.TransactionID,
+		Status:        status,
+		PaymentMethod: PaymentMethodBoleto,
+		Amount:        amount,
+		Currency:      "BRL",
+		ProcessorRef:  boleto.NossoNumero,
+		Reason:        reason,
+		Market:        RegionBrazil,
+	}); err != nil {
+		c.logger.ErrorWithContext(ctx, "Falha ao agendar notificação do boleto",
+			"boleto_id", boleto.BoletoID,
+			"transaction_id", boleto.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// recordAudit grava o evento de auditoria do boleto; falhas são registadas sem interromper a operação
+func (c *BoletoConnector) recordAudit(ctx context.Context, boleto *Boleto, eventType, actor string, details map[string]interface{}) {
+	event := &BoletoAuditEvent{
+		EventID:       uuid.New().String(),
+		EventType:     eventType,
+		TenantID:      boleto.TenantID,
+		BoletoID:      boleto.BoletoID,
+		MerchantID:    boleto.MerchantID,
+		BankCode:      boleto.BankCode,
+		NossoNumero:   boleto.NossoNumero,
+		Amount:        boleto.Amount,
+		PayerDocument: boleto.Payer.Document,
+		Status:        boleto.Status,
+		Actor:         actor,
+		Details:       details,
+		OccurredAt:    c.now(),
+	}
+
+	if err := c.store.SaveAuditEvent(ctx, event); err != nil {
+		c.logger.ErrorWithContext(ctx, "Falha ao gravar evento de auditoria do boleto",
+			"boleto_id", boleto.BoletoID,
+			"event_type", eventType,
+			"error", err.Error())
+	}
+	c.metricsRecorder.CounterInc("payment_boleto_audit_events_total", map[string]string{
+		"event_type": eventType,
+	})
+}
+
+// validateIssueRequest valida o pedido de emissão
+func (c *BoletoConnector) validateIssueRequest(req *BoletoIssueRequest) error {
+	today := c.today()
+	due := time.Date(req.DueDate.Year(), req.DueDate.Month(), req.DueDate.Day(), 0, 0, 0, 0, c.location)
+	document := onlyDigits(req.Payer.Document)
+
+	switch {
+	case req.TenantID == "":
+		return fmt.Errorf("%w: tenant_id é obrigatório", ErrBoletoInvalidRequest)
+	case req.Amount <= 0:
+		return fmt.Errorf("%w: valor do boleto deve ser positivo", ErrBoletoInvalidRequest)
+	case req.DueDate.IsZero() || due.Before(today):
+		return fmt.Errorf("%w: data de vencimento deve ser hoje ou posterior", ErrBoletoInvalidRequest)
+	case due.After(today.AddDate(0, 0, c.config.MaxDueDays)):
+		return fmt.Errorf("%w: vencimento excede %d dias", ErrBoletoInvalidRequest, c.config.MaxDueDays)
+	case len(document) != 11 && len(document) != 14:
+		return fmt.Errorf("%w: documento do pagador deve ser um CPF ou CNPJ", ErrBoletoInvalidRequest)
+	case strings.TrimSpace(req.Payer.Name) == "":
+		return fmt.Errorf("%w: nome do pagador é obrigatório", ErrBoletoInvalidRequest)
+	case len(onlyDigits(req.Payer.PostalCode)) != 8:
+		return fmt.Errorf("%w: CEP do pagador deve ter 8 dígitos", ErrBoletoInvalidRequest)
+	case req.FinePercent < 0 || req.FinePercent > 2:
+		// Código de Defesa do Consumidor limita a multa moratória a 2%
+		return fmt.Errorf("%w: multa deve estar entre 0%% e 2%%", ErrBoletoInvalidRequest)
+	case req.InterestPerDay < 0:
+		return fmt.Errorf("%w: juros de mora não podem ser negativos", ErrBoletoInvalidRequest)
+	}
+	return nil
+}
+
+// registrationPayload monta o corpo do pedido de registro na API do banco parceiro
+func (c *BoletoConnector) registrationPayload(boleto *Boleto) map[string]interface{} {
+	return map[string]interface{}{
+		"nosso_numero":     boleto.NossoNumero,
+		"numero_documento": boleto.DocumentNumber,
+		"valor":            fmt.Sprintf("%.2f", boleto.Amount),
+		"vencimento":       boleto.DueDate.Format("2006-01-02"),
+		"codigo_barras":    boleto.Barcode,
+		"linha_digitavel":  onlyDigits(boleto.LinhaDigitavel),
+		"multa_percentual": boleto.FinePercent,
+		"juros_dia":        boleto.InterestPerDay,
+		"instrucoes":       boleto.Instructions,
+		"prazo_baixa_dias": c.config.WriteOffAfterDays,
+		"beneficiario": map[string]string{
+			"documento": onlyDigits(c.config.BeneficiaryDocument),
+			"convenio":  c.config.Agreement,
+			"agencia":   c.config.Agency,
+			"conta":     c.config.Account,
+			"carteira":  c.config.Wallet,
+		},
+		"pagador": map[string]string{
+			"nome":      boleto.Payer.Name,
+			"documento": boleto.Payer.Document,
+			"endereco":  boleto.Payer.Address,
+			"cidade":    boleto.Payer.City,
+			"uf":        boleto.Payer.State,
+			"cep":       onlyDigits(boleto.Payer.PostalCode),
+		},
+	}
+}
+
+// beneficiary retorna a identificação do beneficiário usada nos arquivos CNAB 240
+func (c *BoletoConnector) beneficiary() CNAB240Beneficiary {
+	return CNAB240Beneficiary{
+		BankCode:     c.config.BankCode,
+		BankName:     c.config.BankName,
+		Document:     onlyDigits(c.config.BeneficiaryDocument),
+		Name:         c.config.BeneficiaryName,
+		Agreement:    c.config.Agreement,
+		Agency:       c.config.Agency,
+		Account:      c.config.Account,
+		Wallet:       c.config.Wallet,
+		WriteOffDays: c.config.WriteOffAfterDays,
+	}
+}
+
+// today retorna o início do dia corrente no fuso de Brasília
+func (c *BoletoConnector) today() time.Time {
+	now := c.now().In(c.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, c.location)
+}
+
+// dueDay retorna o dia de vencimento do boleto no fuso de Brasília
+func (c *BoletoConnector) dueDay(boleto *Boleto) time.Time {
+	return time.Date(boleto.DueDate.Year(), boleto.DueDate.Month(), boleto.DueDate.Day(), 0, 0, 0, 0, c.location)
+}
+
+// sendJSON envia um corpo JSON à API do banco parceiro e decodifica a resposta
+// A idempotencyKey permite repetir o pedido segundo a política sem registos duplicados.
+// Respostas 4xx indicam rejeição do pedido; falhas de rede e respostas 5xx indicam indisponibilidade
+func (c *BoletoConnector) sendJSON(ctx context.Context, method, endpoint, idempotencyKey string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if idempotencyKey != "" {
+			req.Header.Set(resilience.IdempotencyKeyHeader, idempotencyKey)
+		}
+		if c.config.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBoletoBankUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBoletoBankUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s %s retornou status %d", ErrBoletoBankUnavailable, method, resp.Request.URL.Path, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		var rejection boletoRegistrationResponse
+		if json.Unmarshal(body, &rejection) == nil && rejection.Reason != "" {
+			return &boletoRejectionError{reason: rejection.Reason}
+		}
+		return &boletoRejectionError{reason: fmt.Sprintf("%s %s retornou status %d", method, resp.Request.URL.Path, resp.StatusCode)}
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
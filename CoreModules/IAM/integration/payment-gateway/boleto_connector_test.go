@@ -0,0 +1,647 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// fakeBoletoBank simula a API de registro do banco parceiro
+type fakeBoletoBank struct {
+	mutex       sync.Mutex
+	unavailable bool
+	writeOffs   []string
+}
+
+func (b *fakeBoletoBank) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.unavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/baixa") {
+		b.writeOffs = append(b.writeOffs, strings.Split(r.URL.Path, "/")[3])
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var payload struct {
+		NossoNumero string            `json:"nosso_numero"`
+		Pagador     map[string]string `json:"pagador"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+	if payload.Pagador["nome"] == "PAGADOR REJEITADO" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]string{"motivo": "pagador sem cadastro"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": "BB-" + payload.NossoNumero, "status": "REGISTRADO"})
+}
+
+func (b *fakeBoletoBank) setUnavailable(unavailable bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.unavailable = unavailable
+}
+
+// newTestBoletoConnector cria o conector de boletos com o banco parceiro simulado e relógio controlado
+func newTestBoletoConnector(t *testing.T) (*BoletoConnector, *fakeBoletoBank, *time.Time) {
+	t.Helper()
+
+	bank := &fakeBoletoBank{}
+	server := httptest.NewServer(bank)
+	t.Cleanup(server.Close)
+
+	connector, err := NewBoletoConnector(BoletoConfig{
+		BankCode:            "001",
+		BankName:            "Banco do Brasil",
+		Agreement:           "1234567",
+		Agency:              "1234",
+		Account:             "98765",
+		Wallet:              "17",
+		BeneficiaryName:     "InnovaBiz Pagamentos Ltda",
+		BeneficiaryDocument: "11.222.333/0001-81",
+		RegistrationAPIURL:  server.URL,
+		Resilience:          resilience.Policy{MaxAttempts: 1},
+	}, NewInMemoryBoletoStore())
+	require.NoError(t, err)
+
+	// Meio-dia em Brasília
+	clock := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	connector.now = func() time.Time { return clock }
+	return connector, bank, &clock
+}
+
+// testBoletoIssueRequest cria um pedido de emissão válido com vencimento no dia indicado
+func testBoletoIssueRequest(transactionID string, amount float64, dueDate time.Time) *BoletoIssueRequest {
+	return &BoletoIssueRequest{
+		TenantID:      "tenant-1",
+		MerchantID:    "merchant-1",
+		TransactionID: transactionID,
+		Amount:        amount,
+		DueDate:       dueDate,
+		Payer: BoletoPayer{
+			Name:       "João da Silva",
+			Document:   "123.456.789-09",
+			Address:    "Rua das Flores, 100",
+			City:       "São Paulo",
+			State:      "SP",
+			PostalCode: "01310-100",
+		},
+	}
+}
+
+// testRetornoTitle descreve um título do arquivo de retorno (segmentos T e U)
+type testRetornoTitle struct {
+	movement    string
+	nossoNumero string
+	amount      float64
+	paid        float64
+	fee         float64
+	reasons     string
+	occurredAt  time.Time
+}
+
+// buildTestCNAB240Retorno monta um arquivo de retorno CNAB 240 do banco com os títulos indicados
+func buildTestCNAB240Retorno(bankCode string, sequence int, titles ...testRetornoTitle) string {
+	var lines []cnab240Line
+
+	header := newCNAB240Line()
+	header.num(1, 3, bankCode)
+	header.num(8, 8, "0")
+	header.num(143, 143, cnab240FileRetorno)
+	header.num(144, 151, "17102026")
+	header.num(152, 157, "063000")
+	header.num(158, 163, strconv.Itoa(sequence))
+	lines = append(lines, header)
+
+	batch := newCNAB240Line()
+	batch.num(1, 3, bankCode)
+	batch.num(8, 8, "1")
+	lines = append(lines, batch)
+
+	for _, title := range titles {
+		segmentT := newCNAB240Line()
+		segmentT.num(1, 3, bankCode)
+		segmentT.num(8, 8, "3")
+		segmentT.alpha(14, 14, "T")
+		segmentT.num(16, 17, title.movement)
+		segmentT.alpha(38, 57, title.nossoNumero)
+		segmentT.date(74, 81, time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC))
+		segmentT.amount(82, 96, title.amount)
+		segmentT.num(134, 148, "12345678909")
+		segmentT.alpha(149, 188, "Joao da Silva")
+		segmentT.amount(199, 213, title.fee)
+		segmentT.alpha(214, 223, title.reasons)
+		lines = append(lines, segmentT)
+
+		segmentU := newCNAB240Line()
+		segmentU.num(1, 3, bankCode)
+		segmentU.num(8, 8, "3")
+		segmentU.alpha(14, 14, "U")
+		segmentU.num(16, 17, title.movement)
+		segmentU.amount(18, 32, 0)
+		segmentU.amount(78, 92, title.paid)
+		segmentU.amount(93, 107, title.paid-title.fee)
+		segmentU.date(138, 145, title.occurredAt)
+		segmentU.date(146, 153, title.occurredAt)
+		lines = append(lines, segmentU)
+	}
+
+	batchTrailer := newCNAB240Line()
+	batchTrailer.num(1, 3, bankCode)
+	batchTrailer.num(8, 8, "5")
+	batchTrailer.num(18, 23, strconv.Itoa(len(titles)*2+2))
+	lines = append(lines, batchTrailer)
+
+	fileTrailer := newCNAB240Line()
+	fileTrailer.num(1, 3, bankCode)
+	fileTrailer.num(8, 8, "9")
+	fileTrailer.num(24, 29, strconv.Itoa(len(lines)+1))
+	lines = append(lines, fileTrailer)
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.Write(line)
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+func TestBuildBoletoBarcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		bankCode  string
+		dueDate   time.Time
+		amount    float64
+		freeField string
+		barcode   string
+		factor    string
+		wantErr   bool
+	}{
+		{
+			name:      "exemplo FEBRABAN do Banco do Brasil",
+			bankCode:  "001",
+			dueDate:   time.Date(2007, 12, 31, 0, 0, 0, 0, time.UTC),
+			amount:    1.00,
+			freeField: "0500940144816060680935031",
+			barcode:   "00193373700000001000500940144816060680935031",
+			factor:    "3737",
+		},
+		{
+			name:      "último dia antes do reinício do fator",
+			bankCode:  "001",
+			dueDate:   time.Date(2025, 2, 21, 0, 0, 0, 0, time.UTC),
+			amount:    150.75,
+			freeField: "1234170000000000109876500",
+			factor:    "9999",
+		},
+		{
+			name:      "reinício do fator em 22/02/2025",
+			bankCode:  "001",
+			dueDate:   time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC),
+			amount:    150.75,
+			freeField: "1234170000000000109876500",
+			factor:    "1000",
+		},
+		{
+			name:      "código do banco inválido",
+			bankCode:  "01",
+			dueDate:   time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC),
+			amount:    10,
+			freeField: "1234170000000000109876500",
+			wantErr:   true,
+		},
+		{
+			name:      "campo livre incompleto",
+			bankCode:  "001",
+			dueDate:   time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC),
+			amount:    10,
+			freeField: "123417",
+			wantErr:   true,
+		},
+		{
+			name:      "valor nulo",
+			bankCode:  "001",
+			dueDate:   time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC),
+			amount:    0,
+			freeField: "1234170000000000109876500",
+			wantErr:   true,
+		},
+		{
+			name:      "vencimento anterior ao fator mínimo",
+			bankCode:  "001",
+			dueDate:   time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+			amount:    10,
+			freeField: "1234170000000000109876500",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			barcode, err := BuildBoletoBarcode(tt.bankCode, tt.dueDate, tt.amount, tt.freeField)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrBoletoInvalidRequest)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, barcode, 44)
+			if tt.barcode != "" {
+				assert.Equal(t, tt.barcode, barcode)
+			}
+			assert.Equal(t, tt.factor, barcode[5:9])
+			assert.Equal(t, boletoBarcodeDV(barcode[:4]+barcode[5:]), barcode[4:5])
+		})
+	}
+}
+
+func TestBoletoLinhaDigitavel(t *testing.T) {
+	barcode := "00193373700000001000500940144816060680935031"
+	linha, err := BoletoLinhaDigitavel(barcode)
+	require.NoError(t, err)
+	assert.Equal(t, "00190.50095 40144.816069 06809.350314 3 37370000000100", linha)
+
+	tests := []struct {
+		name    string
+		linha   string
+		wantErr bool
+	}{
+		{name: "linha formatada", linha: linha},
+		{name: "apenas dígitos", linha: onlyDigits(linha)},
+		{name: "dígito do primeiro campo", linha: "00190.50096 40144.816069 06809.350314 3 37370000000100", wantErr: true},
+		{name: "dígito do segundo campo", linha: "00190.50095 40144.816068 06809.350314 3 37370000000100", wantErr: true},
+		{name: "dígito do terceiro campo", linha: "00190.50095 40144.816069 06809.350315 3 37370000000100", wantErr: true},
+		{name: "dígito verificador geral", linha: "00190.50095 40144.816069 06809.350314 4 37370000000100", wantErr: true},
+		{name: "valor adulterado", linha: "00190.50095 40144.816069 06809.350314 3 37370000000900", wantErr: true},
+		{name: "linha incompleta", linha: "00190.50095 40144.816069 06809.350314 3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseBoletoLinhaDigitavel(tt.linha)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrBoletoInvalidRequest)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, barcode, parsed)
+		})
+	}
+
+	_, err = BoletoLinhaDigitavel(barcode[:43])
+	assert.ErrorIs(t, err, ErrBoletoInvalidRequest)
+}
+
+func TestParseCNAB240Retorno(t *testing.T) {
+	occurredAt := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	content := buildTestCNAB240Retorno("001", 7,
+		testRetornoTitle{movement: CNAB240RetornoSettled, nossoNumero: "1", amount: 150.75, paid: 150.75, fee: 1.5, occurredAt: occurredAt},
+		testRetornoTitle{movement: CNAB240RetornoEntryRejected, nossoNumero: "00000000002", amount: 80, reasons: "0845"},
+	)
+
+	file, err := ParseCNAB240(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, "001", file.BankCode)
+	assert.True(t, file.IsRetorno)
+	assert.Equal(t, 7, file.Sequence)
+	assert.Equal(t, time.Date(2026, 10, 17, 6, 30, 0, 0, time.UTC), file.GeneratedAt)
+	require.Len(t, file.Titles, 2)
+
+	settled := file.Titles[0]
+	assert.Equal(t, CNAB240RetornoSettled, settled.MovementCode)
+	assert.Equal(t, "00000000001", settled.NossoNumero)
+	assert.Equal(t, 150.75, settled.Amount)
+	assert.Equal(t, 150.75, settled.PaidAmount)
+	assert.Equal(t, 149.25, settled.NetAmount)
+	assert.Equal(t, 1.5, settled.Fee)
+	assert.Equal(t, "12345678909", settled.PayerDocument)
+	assert.Equal(t, "JOAO DA SILVA", settled.PayerName)
+	require.NotNil(t, settled.OccurrenceDate)
+	assert.Equal(t, occurredAt, *settled.OccurrenceDate)
+	assert.Empty(t, settled.RejectionReasons)
+
+	rejected := file.Titles[1]
+	assert.Equal(t, []string{"08", "45"}, rejected.RejectionReasons)
+	assert.Nil(t, rejected.OccurrenceDate)
+	assert.Nil(t, rejected.CreditDate)
+
+	valid := strings.Split(strings.TrimSuffix(content, "\r\n"), "\r\n")
+	join := func(lines ...string) string { return strings.Join(lines, "\r\n") }
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "linha com tamanho inválido", content: join(append([]string{valid[0][:239]}, valid[1:]...)...)},
+		{name: "trailer de lote com total divergente", content: join(append(append([]string{}, valid[:2]...), valid[4:]...)...)},
+		{name: "trailer de arquivo com total divergente", content: join(valid[0], valid[len(valid)-1])},
+		{name: "segmento U sem segmento T", content: join(valid[0], valid[1], valid[3], valid[3])},
+		{name: "conteúdo após o trailer", content: join(append(append([]string{}, valid...), valid[1])...)},
+		{name: "sem trailer de arquivo", content: join(valid[:len(valid)-1]...)},
+		{name: "tipo de registro desconhecido", content: join(valid[0], strings.Repeat("0", 7)+"7"+strings.Repeat(" ", 232))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCNAB240(strings.NewReader(tt.content))
+			assert.ErrorIs(t, err, ErrCNAB240InvalidFile)
+		})
+	}
+}
+
+func TestBoletoIssueAndRemessa(t *testing.T) {
+	ctx := context.Background()
+	connector, bank, clock := newTestBoletoConnector(t)
+	dueDate := time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)
+
+	boleto, err := connector.IssueBoleto(ctx, testBoletoIssueRequest("tx-1", 150.75, dueDate))
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusRegistered, boleto.Status)
+	assert.Equal(t, "BB-00000000001", boleto.BankReference)
+	assert.Equal(t, "12345678909", boleto.Payer.Document)
+	assert.Equal(t, "0019", boleto.Barcode[:4])
+	assert.Equal(t, "0000015075", boleto.Barcode[9:19])
+	parsed, err := ParseBoletoLinhaDigitavel(boleto.LinhaDigitavel)
+	require.NoError(t, err)
+	assert.Equal(t, boleto.Barcode, parsed)
+
+	// Rejeição do banco fica no estado do boleto
+	rejectedRequest := testBoletoIssueRequest("tx-2", 80, dueDate)
+	rejectedRequest.Payer.Name = "PAGADOR REJEITADO"
+	rejected, err := connector.IssueBoleto(ctx, rejectedRequest)
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusRegistrationFailed, rejected.Status)
+	assert.Equal(t, "pagador sem cadastro", rejected.FailureReason)
+
+	// Banco indisponível: o boleto fica pendente e segue na remessa CNAB 240
+	bank.setUnavailable(true)
+	pending, err := connector.IssueBoleto(ctx, testBoletoIssueRequest("tx-3", 42.1, dueDate))
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusPendingRegistration, pending.Status)
+
+	remessa, sequence, err := connector.GenerateRemessa(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sequence)
+	file, err := ParseCNAB240(bytes.NewReader(remessa))
+	require.NoError(t, err)
+	assert.False(t, file.IsRetorno)
+	assert.Equal(t, "001", file.BankCode)
+	require.Len(t, file.Titles, 1)
+	assert.Equal(t, CNAB240RemessaEntry, file.Titles[0].MovementCode)
+	assert.Equal(t, pending.NossoNumero, file.Titles[0].NossoNumero)
+	assert.Equal(t, 42.1, file.Titles[0].Amount)
+	assert.Equal(t, "12345678909", file.Titles[0].PayerDocument)
+	assert.Equal(t, "JOAO DA SILVA", file.Titles[0].PayerName)
+
+	// A varredura regista o boleto pendente quando o banco volta a responder
+	bank.setUnavailable(false)
+	connector.Sweep(ctx)
+	pending, err = connector.GetBoleto(ctx, "tenant-1", pending.BoletoID)
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusRegistered, pending.Status)
+
+	tests := []struct {
+		name   string
+		modify func(req *BoletoIssueRequest)
+	}{
+		{name: "vencimento no passado", modify: func(req *BoletoIssueRequest) { req.DueDate = clock.AddDate(0, 0, -1) }},
+		{name: "vencimento acima do prazo máximo", modify: func(req *BoletoIssueRequest) { req.DueDate = clock.AddDate(0, 0, 400) }},
+		{name: "valor nulo", modify: func(req *BoletoIssueRequest) { req.Amount = 0 }},
+		{name: "documento do pagador inválido", modify: func(req *BoletoIssueRequest) { req.Payer.Document = "1234" }},
+		{name: "CEP incompleto", modify: func(req *BoletoIssueRequest) { req.Payer.PostalCode = "0131" }},
+		{name: "multa acima de 2%", modify: func(req *BoletoIssueRequest) { req.FinePercent = 2.5 }},
+		{name: "juros negativos", modify: func(req *BoletoIssueRequest) { req.InterestPerDay = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testBoletoIssueRequest("tx-invalid", 10, dueDate)
+			tt.modify(req)
+			_, err := connector.IssueBoleto(ctx, req)
+			assert.ErrorIs(t, err, ErrBoletoInvalidRequest)
+		})
+	}
+
+	// Vencimento no próprio dia é aceite
+	_, err = connector.IssueBoleto(ctx, testBoletoIssueRequest("tx-4", 10, *clock))
+	assert.NoError(t, err)
+}
+
+func TestBoletoReconcileRetorno(t *testing.T) {
+	ctx := context.Background()
+	connector, bank, _ := newTestBoletoConnector(t)
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	connector.SetWebhookDeliveryService(webhooks)
+	dueDate := time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)
+
+	issue := func(transactionID string, amount float64) *Boleto {
+		boleto, err := connector.IssueBoleto(ctx, testBoletoIssueRequest(transactionID, amount, dueDate))
+		require.NoError(t, err)
+		return boleto
+	}
+	paid := issue("tx-1", 150.75)
+	underpaid := issue("tx-2", 100)
+	writtenOff := issue("tx-3", 60)
+	bank.setUnavailable(true)
+	confirmed := issue("tx-4", 30)
+	refused := issue("tx-5", 20)
+	bank.setUnavailable(false)
+
+	occurredAt := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	content := buildTestCNAB240Retorno("001", 12,
+		testRetornoTitle{movement: CNAB240RetornoSettled, nossoNumero: paid.NossoNumero, amount: 150.75, paid: 150.75, fee: 1.5, occurredAt: occurredAt},
+		testRetornoTitle{movement: CNAB240RetornoSettled, nossoNumero: underpaid.NossoNumero, amount: 100, paid: 90, occurredAt: occurredAt},
+		testRetornoTitle{movement: CNAB240RetornoWrittenOff, nossoNumero: writtenOff.NossoNumero, amount: 60},
+		testRetornoTitle{movement: CNAB240RetornoEntryConfirmed, nossoNumero: confirmed.NossoNumero, amount: 30},
+		testRetornoTitle{movement: CNAB240RetornoEntryRejected, nossoNumero: refused.NossoNumero, amount: 20, reasons: "08"},
+		testRetornoTitle{movement: CNAB240RetornoSettled, nossoNumero: "99999999999", amount: 10, paid: 10, occurredAt: occurredAt},
+		testRetornoTitle{movement: "28", nossoNumero: paid.NossoNumero, amount: 150.75},
+	)
+
+	result, err := connector.ReconcileRetorno(ctx, strings.NewReader(content), "operador-1")
+	require.NoError(t, err)
+	assert.Equal(t, 12, result.FileSequence)
+	assert.Equal(t, 7, result.Records)
+	assert.Equal(t, 2, result.Paid)
+	assert.Equal(t, 1, result.WrittenOff)
+	assert.Equal(t, 1, result.Registered)
+	assert.Equal(t, 1, result.Rejected)
+	assert.Equal(t, 1, result.Ignored)
+	require.Len(t, result.Divergences, 1)
+	assert.Equal(t, underpaid.NossoNumero, result.Divergences[0].NossoNumero)
+	assert.Equal(t, 100.0, result.Divergences[0].ExpectedAmount)
+	assert.Equal(t, 90.0, result.Divergences[0].PaidAmount)
+	require.Len(t, result.Unknown, 1)
+	assert.Equal(t, "99999999999", result.Unknown[0].NossoNumero)
+
+	current, err := connector.GetBoleto(ctx, "tenant-1", paid.BoletoID)
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusPaid, current.Status)
+	assert.Equal(t, 1.5, current.BankFee)
+	require.NotNil(t, current.PaidAt)
+	assert.Equal(t, occurredAt, *current.PaidAt)
+	current, err = connector.GetBoleto(ctx, "tenant-1", refused.BoletoID)
+	require.NoError(t, err)
+	assert.Equal(t, BoletoStatusRegistrationFailed, current.Status)
+	assert.Equal(t, "motivos CNAB: 08", current.FailureReason)
+
+	// Reprocessar o mesmo retorno não liquida os boletos duas vezes
+	result, err = connector.ReconcileRetorno(ctx, strings.NewReader(content), "operador-1")
+	require.NoError(t, err)
+	assert.Zero(t, result.Paid)
+	assert.Zero(t, result.WrittenOff)
+
+	events, err := connector.AuditEvents(ctx, "tenant-1", time.Time{}, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	var divergences int
+	for _, event := range events {
+		if event.EventType == BoletoEventAmountDivergence {
+			divergences++
+			assert.Equal(t, underpaid.BoletoID, event.BoletoID)
+			assert.Equal(t, "operador-1", event.Actor)
+		}
+	}
+	assert.Equal(t, 1, divergences)
+
+	// O comerciante é notificado das liquidações e da baixa
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	notified := make(map[string]WebhookEvent)
+	for _, delivery := range list {
+		notified[delivery.Event.TransactionID] = delivery.Event
+	}
+	require.Len(t, notified, 3)
+	assert.Equal(t, WebhookEventTransactionCompleted, notified["tx-1"].EventType)
+	assert.Equal(t, PaymentMethodBoleto, notified["tx-1"].PaymentMethod)
+	assert.Equal(t, paid.NossoNumero, notified["tx-1"].ProcessorRef)
+	assert.Equal(t, WebhookEventTransactionCompleted, notified["tx-2"].EventType)
+	assert.Equal(t, 90.0, notified["tx-2"].Amount)
+	assert.Equal(t, WebhookEventTransactionFailed, notified["tx-3"].EventType)
+
+	// Arquivos de outro banco ou de remessa são recusados
+	_, err = connector.ReconcileRetorno(ctx, strings.NewReader(buildTestCNAB240Retorno("237", 13)), "operador-1")
+	assert.ErrorIs(t, err, ErrCNAB240InvalidFile)
+	remessa, _, err := connector.GenerateRemessa(ctx)
+	require.NoError(t, err)
+	_, err = connector.ReconcileRetorno(ctx, bytes.NewReader(remessa), "operador-1")
+	assert.ErrorIs(t, err, ErrCNAB240InvalidFile)
+}
+
+func TestBoletoSweepExpiryAndWriteOff(t *testing.T) {
+	ctx := context.Background()
+	connector, bank, clock := newTestBoletoConnector(t)
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	connector.SetWebhookDeliveryService(webhooks)
+
+	dueDate := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	boleto, err := connector.IssueBoleto(ctx, testBoletoIssueRequest("tx-1", 150, dueDate))
+	require.NoError(t, err)
+	late, err := connector.IssueBoleto(ctx, testBoletoIssueRequest("tx-2", 80, dueDate))
+	require.NoError(t, err)
+
+	status := func(boletoID string) BoletoStatus {
+		current, err := connector.GetBoleto(ctx, "tenant-1", boletoID)
+		require.NoError(t, err)
+		return current.Status
+	}
+	at := func(year int, month time.Month, day, hour int) {
+		*clock = time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+		connector.Sweep(ctx)
+	}
+
+	// No dia do vencimento o boleto ainda pode ser pago
+	at(2026, 10, 20, 15)
+	assert.Equal(t, BoletoStatusRegistered, status(boleto.BoletoID))
+
+	at(2026, 10, 21, 15)
+	assert.Equal(t, BoletoStatusExpired, status(boleto.BoletoID))
+
+	// Boleto vencido ainda é liquidado quando o pagamento chega no retorno
+	content := buildTestCNAB240Retorno("001", 1, testRetornoTitle{
+		movement: CNAB240RetornoSettledAfter, nossoNumero: late.NossoNumero, amount: 80, paid: 82.5, occurredAt: clock.Truncate(24 * time.Hour),
+	})
+	result, err := connector.ReconcileRetorno(ctx, strings.NewReader(content), "operador-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Paid)
+	assert.Empty(t, result.Divergences)
+	assert.Equal(t, BoletoStatusPaid, status(late.BoletoID))
+
+	// A baixa automática ocorre após o prazo configurado contado do vencimento
+	at(2026, 11, 19, 15)
+	assert.Equal(t, BoletoStatusExpired, status(boleto.BoletoID))
+	assert.Empty(t, bank.writeOffs)
+
+	at(2026, 11, 20, 15)
+	assert.Equal(t, BoletoStatusWrittenOff, status(boleto.BoletoID))
+	assert.Equal(t, []string{boleto.NossoNumero}, bank.writeOffs)
+	assert.Equal(t, BoletoStatusPaid, status(late.BoletoID))
+
+	// Boletos liquidados ou baixados não aceitam nova baixa
+	_, err = connector.WriteOffBoleto(ctx, "tenant-1", late.BoletoID, "pedido do comerciante", "operador-1")
+	assert.ErrorIs(t, err, ErrBoletoInvalidTransition)
+
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, delivery := range list {
+		switch delivery.Event.TransactionID {
+		case "tx-1":
+			assert.Equal(t, WebhookEventTransactionFailed, delivery.Event.EventType)
+			assert.Contains(t, delivery.Event.Reason, "baixa automática")
+		case "tx-2":
+			assert.Equal(t, WebhookEventTransactionCompleted, delivery.Event.EventType)
+		}
+	}
+}
+
+func TestProcessPaymentBoletoPending(t *testing.T) {
+	ctx := context.Background()
+	connector, _ := newTestConnector(t)
+	boletos, _, clock := newTestBoletoConnector(t)
+	connector.SetBoletoConnector(boletos)
+
+	details := &BoletoPaymentDetails{
+		DueDate: clock.AddDate(0, 0, 3),
+		Payer:   testBoletoIssueRequest("", 0, time.Time{}).Payer,
+	}
+	req := testRiskRequest(RegionBrazil, "BRL", 150)
+	req.PaymentMethod = PaymentMethodBoleto
+	req.Boleto = details
+
+	response, err := connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusPending, response.Status)
+	assert.Equal(t, "boleto_emitido", response.StatusCode)
+	require.Contains(t, response.Metadata, "boleto_id")
+	assert.Equal(t, BoletoStatusRegistered, response.Metadata["boleto_status"])
+
+	boleto, err := boletos.GetBoleto(ctx, "tenant-1", response.Metadata["boleto_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", boleto.TransactionID)
+	assert.Equal(t, boleto.LinhaDigitavel, response.Metadata["boleto_linha_digitavel"])
+
+	// Boletos são emitidos apenas em reais
+	req = testRiskRequest(RegionBrazil, "USD", 150)
+	req.RequestID = "req-2"
+	req.TransactionID = "tx-2"
+	req.PaymentMethod = PaymentMethodBoleto
+	req.Boleto = details
+	response, err = connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "boleto_recusado", response.StatusCode)
+	assert.NotContains(t, response.Metadata, "boleto_id")
+}
@@ -0,0 +1,172 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Tamanho máximo aceite para arquivos de retorno CNAB 240
+const maxCNAB240RetornoSize = 20 << 20
+
+// BoletoHandler expõe a API HTTP de emissão, baixa e conciliação de boletos
+type BoletoHandler struct {
+	connector *BoletoConnector
+}
+
+// NewBoletoHandler cria uma nova instância do BoletoHandler
+func NewBoletoHandler(connector *BoletoConnector) *BoletoHandler {
+	return &BoletoHandler{connector: connector}
+}
+
+// boletoWriteOffRequest representa a requisição de baixa de um boleto
+type boletoWriteOffRequest struct {
+	Reason  string `json:"reason"`
+	ActorID string `json:"actor_id"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *BoletoHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/boletos", h.IssueBoleto).Methods(http.MethodPost)
+	router.HandleFunc("/boletos/audit-events", h.ListAuditEvents).Methods(http.MethodGet)
+	router.HandleFunc("/boletos/cnab240/remessa", h.GenerateRemessa).Methods(http.MethodPost)
+	router.HandleFunc("/boletos/cnab240/retorno", h.UploadRetorno).Methods(http.MethodPost)
+	router.HandleFunc("/boletos/{boletoId}", h.GetBoleto).Methods(http.MethodGet)
+	router.HandleFunc("/boletos/{boletoId}/register", h.RegisterBoleto).Methods(http.MethodPost)
+	router.HandleFunc("/boletos/{boletoId}/write-off", h.WriteOffBoleto).Methods(http.MethodPost)
+}
+
+// IssueBoleto emite um boleto e retorna o código de barras e a linha digitável
+func (h *BoletoHandler) IssueBoleto(w http.ResponseWriter, r *http.Request) {
+	var req BoletoIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	boleto, err := h.connector.IssueBoleto(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, boleto)
+}
+
+// GetBoleto retorna o boleto e o seu estado
+func (h *BoletoHandler) GetBoleto(w http.ResponseWriter, r *http.Request) {
+	boleto, err := h.connector.GetBoleto(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["boletoId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, boleto)
+}
+
+// RegisterBoleto repete o registro de um boleto pendente ou rejeitado
+func (h *BoletoHandler) RegisterBoleto(w http.ResponseWriter, r *http.Request) {
+	boleto, err := h.connector.RegisterBoleto(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["boletoId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, boleto)
+}
+
+// WriteOffBoleto baixa um boleto não pago
+func (h *BoletoHandler) WriteOffBoleto(w http.ResponseWriter, r *http.Request) {
+	var req boletoWriteOffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	if req.Reason == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Motivo da baixa é obrigatório")
+		return
+	}
+	if req.ActorID == "" {
+		req.ActorID = r.Header.Get("X-User-ID")
+	}
+
+	boleto, err := h.connector.WriteOffBoleto(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["boletoId"], req.Reason, req.ActorID)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, boleto)
+}
+
+// GenerateRemessa gera o arquivo CNAB 240 de remessa dos boletos pendentes de registro
+func (h *BoletoHandler) GenerateRemessa(w http.ResponseWriter, r *http.Request) {
+	file, sequence, err := h.connector.GenerateRemessa(r.Context())
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=us-ascii")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"remessa-%06d.rem\"", sequence))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file)
+}
+
+// UploadRetorno recebe um arquivo CNAB 240 de retorno e concilia os boletos
+func (h *BoletoHandler) UploadRetorno(w http.ResponseWriter, r *http.Request) {
+	actor := r.Header.Get("X-User-ID")
+	result, err := h.connector.ReconcileRetorno(r.Context(), io.LimitReader(r.Body, maxCNAB240RetornoSize), actor)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ListAuditEvents retorna os eventos de auditoria do tenant no período (AAAA-MM-DD, fim exclusivo)
+func (h *BoletoHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro from inválido (AAAA-MM-DD)")
+		return
+	}
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil || !to.After(from) {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro to inválido (AAAA-MM-DD, posterior a from)")
+		return
+	}
+
+	events, err := h.connector.AuditEvents(r.Context(), r.Header.Get("X-Tenant-ID"), from, to)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, events)
+}
+
+// respondWithServiceError converte erros do conector em respostas HTTP
+func (h *BoletoHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBoletoNotFound):
+		respondWithError(w, http.StatusNotFound, "boleto_not_found", err.Error())
+	case errors.Is(err, ErrBoletoInvalidRequest), errors.Is(err, ErrCNAB240InvalidFile):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrBoletoInvalidTransition):
+		respondWithError(w, http.StatusConflict, "invalid_transition", err.Error())
+	case errors.Is(err, ErrBoletoRegistrationFailed):
+		respondWithError(w, http.StatusUnprocessableEntity, "registration_rejected", err.Error())
+	case errors.Is(err, ErrBoletoBankUnavailable):
+		respondWithError(w, http.StatusBadGateway, "bank_unavailable", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "boleto_error", err.Error())
+	}
+}
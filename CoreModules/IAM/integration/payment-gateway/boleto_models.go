@@ -0,0 +1,159 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// BoletoStatus representa o estado de um boleto no ciclo de emissão, registro e liquidação
+type BoletoStatus string
+
+const (
+	BoletoStatusPendingRegistration BoletoStatus = "PENDING_REGISTRATION"
+	BoletoStatusRegistered          BoletoStatus = "REGISTERED"
+	BoletoStatusRegistrationFailed  BoletoStatus = "REGISTRATION_FAILED"
+	BoletoStatusPaid                BoletoStatus = "PAID"
+	BoletoStatusExpired             BoletoStatus = "EXPIRED"
+	BoletoStatusWrittenOff          BoletoStatus = "WRITTEN_OFF"
+)
+
+// IsFinal verifica se o boleto já não pode ser pago nem alterado
+func (s BoletoStatus) IsFinal() bool {
+	return s == BoletoStatusPaid || s == BoletoStatusWrittenOff
+}
+
+// Tipos de evento de auditoria do boleto, reportados ao BACEN
+const (
+	BoletoEventIssued             = "BOLETO_EMITIDO"
+	BoletoEventRegistered         = "BOLETO_REGISTRADO"
+	BoletoEventRegistrationFailed = "BOLETO_REGISTRO_REJEITADO"
+	BoletoEventPaid               = "BOLETO_LIQUIDADO"
+	BoletoEventExpired            = "BOLETO_VENCIDO"
+	BoletoEventWrittenOff         = "BOLETO_BAIXADO"
+	BoletoEventAmountDivergence   = "BOLETO_DIVERGENCIA_VALOR"
+	BoletoEventUnknownReturn      = "BOLETO_RETORNO_DESCONHECIDO"
+)
+
+// Valores padrão do conector de boletos
+const (
+	DefaultBoletoHTTPTimeout       = 15 * time.Second
+	DefaultBoletoSweepInterval     = time.Hour
+	DefaultBoletoWriteOffAfterDays = 30
+	DefaultBoletoMaxDueDays        = 365
+
+	// Código da moeda real no código de barras FEBRABAN
+	boletoCurrencyCode = "9"
+)
+
+// Erros do conector de boletos
+var (
+	ErrBoletoNotFound           = errors.New("boleto não encontrado")
+	ErrBoletoInvalidRequest     = errors.New("pedido de emissão de boleto inválido")
+	ErrBoletoInvalidTransition  = errors.New("operação não permitida no estado atual do boleto")
+	ErrBoletoRegistrationFailed = errors.New("registro do boleto rejeitado pelo banco parceiro")
+	ErrBoletoBankUnavailable    = errors.New("API do banco parceiro indisponível")
+	ErrCNAB240InvalidFile       = errors.New("arquivo CNAB 240 inválido")
+)
+
+// BoletoPayer identifica o pagador do boleto
+type BoletoPayer struct {
+	Name       string `json:"name"`
+	Document   string `json:"document"` // CPF (11) ou CNPJ (14) sem pontuação
+	Address    string `json:"address"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+}
+
+// BoletoIssueRequest representa o pedido de emissão de um boleto
+type BoletoIssueRequest struct {
+	TenantID       string      `json:"tenant_id"`
+	MerchantID     string      `json:"merchant_id"`
+	TransactionID  string      `json:"transaction_id"`
+	Amount         float64     `json:"amount"`
+	DueDate        time.Time   `json:"due_date"`
+	Payer          BoletoPayer `json:"payer"`
+	DocumentNumber string      `json:"document_number,omitempty"` // Número do documento (seu número) do comerciante
+	Instructions   []string    `json:"instructions,omitempty"`
+	FinePercent    float64     `json:"fine_percent,omitempty"`     // Multa após o vencimento
+	InterestPerDay float64     `json:"interest_per_day,omitempty"` // Juros de mora diários (valor)
+}
+
+// BoletoPaymentDetails contém os dados do boleto de um pagamento processado pelo gateway
+type BoletoPaymentDetails struct {
+	DueDate        time.Time   `json:"due_date"`
+	Payer          BoletoPayer `json:"payer"`
+	DocumentNumber string      `json:"document_number,omitempty"`
+	Instructions   []string    `json:"instructions,omitempty"`
+	FinePercent    float64     `json:"fine_percent,omitempty"`
+	InterestPerDay float64     `json:"interest_per_day,omitempty"`
+}
+
+// Boleto representa um boleto emitido e o seu estado junto do banco parceiro
+type Boleto struct {
+	BoletoID       string       `json:"boleto_id"`
+	TenantID       string       `json:"tenant_id"`
+	MerchantID     string       `json:"merchant_id"`
+	TransactionID  string       `json:"transaction_id"`
+	BankCode       string       `json:"bank_code"`
+	NossoNumero    string       `json:"nosso_numero"`
+	DocumentNumber string       `json:"document_number,omitempty"`
+	Amount         float64      `json:"amount"`
+	DueDate        time.Time    `json:"due_date"`
+	Payer          BoletoPayer  `json:"payer"`
+	Instructions   []string     `json:"instructions,omitempty"`
+	FinePercent    float64      `json:"fine_percent,omitempty"`
+	InterestPerDay float64      `json:"interest_per_day,omitempty"`
+	Barcode        string       `json:"barcode"`
+	LinhaDigitavel string       `json:"linha_digitavel"`
+	Status         BoletoStatus `json:"status"`
+	BankReference  string       `json:"bank_reference,omitempty"`
+	FailureReason  string       `json:"failure_reason,omitempty"`
+	PaidAmount     float64      `json:"paid_amount,omitempty"`
+	PaidAt         *time.Time   `json:"paid_at,omitempty"`
+	CreditDate     *time.Time   `json:"credit_date,omitempty"`
+	BankFee        float64      `json:"bank_fee,omitempty"`
+	WriteOffReason string       `json:"write_off_reason,omitempty"`
+	WrittenOffAt   *time.Time   `json:"written_off_at,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// BoletoAuditEvent regista uma alteração relevante de um boleto para reporte ao BACEN
+type BoletoAuditEvent struct {
+	EventID       string                 `json:"event_id"`
+	EventType     string                 `json:"event_type"`
+	TenantID      string                 `json:"tenant_id"`
+	BoletoID      string                 `json:"boleto_id,omitempty"`
+	MerchantID    string                 `json:"merchant_id,omitempty"`
+	BankCode      string                 `json:"bank_code"`
+	NossoNumero   string                 `json:"nosso_numero"`
+	Amount        float64                `json:"amount"`
+	PayerDocument string                 `json:"payer_document,omitempty"`
+	Status        BoletoStatus           `json:"status,omitempty"`
+	Actor         string                 `json:"actor"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+}
+
+// BoletoReconciliationResult resume a aplicação de um arquivo de retorno CNAB 240
+type BoletoReconciliationResult struct {
+	FileSequence int                         `json:"file_sequence"`
+	Records      int                         `json:"records"`
+	Registered   int                         `json:"registered"`
+	Rejected     int                         `json:"rejected"`
+	Paid         int                         `json:"paid"`
+	WrittenOff   int                         `json:"written_off"`
+	Ignored      int                         `json:"ignored"`
+	Divergences  []BoletoReconciliationIssue `json:"divergences,omitempty"`
+	Unknown      []BoletoReconciliationIssue `json:"unknown,omitempty"`
+}
+
+// BoletoReconciliationIssue descreve um registo de retorno que exige análise manual
+type BoletoReconciliationIssue struct {
+	NossoNumero    string  `json:"nosso_numero"`
+	MovementCode   string  `json:"movement_code"`
+	ExpectedAmount float64 `json:"expected_amount,omitempty"`
+	PaidAmount     float64 `json:"paid_amount,omitempty"`
+	Reason         string  `json:"reason"`
+}
@@ -0,0 +1,154 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BoletoStore define a persistência dos boletos emitidos e dos seus eventos de auditoria
+type BoletoStore interface {
+	// NextNossoNumero reserva o próximo nosso número da carteira do beneficiário
+	NextNossoNumero(ctx context.Context) (string, error)
+
+	// NextRemessaSequence reserva o próximo número sequencial de arquivo de remessa
+	NextRemessaSequence(ctx context.Context) (int, error)
+
+	// SaveBoleto cria ou atualiza um boleto
+	SaveBoleto(ctx context.Context, boleto *Boleto) error
+
+	// GetBoleto recupera um boleto do tenant
+	GetBoleto(ctx context.Context, tenantID, boletoID string) (*Boleto, error)
+
+	// GetBoletoByNossoNumero recupera um boleto pelo nosso número, usado na conciliação
+	GetBoletoByNossoNumero(ctx context.Context, nossoNumero string) (*Boleto, error)
+
+	// ListBoletosByStatus lista os boletos de todos os tenants num estado, por ordem de vencimento
+	ListBoletosByStatus(ctx context.Context, status BoletoStatus) ([]*Boleto, error)
+
+	// SaveAuditEvent grava um evento de auditoria
+	SaveAuditEvent(ctx context.Context, event *BoletoAuditEvent) error
+
+	// ListAuditEvents lista os eventos de auditoria do tenant no período, por ordem cronológica
+	ListAuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*BoletoAuditEvent, error)
+}
+
+// InMemoryBoletoStore armazena boletos e eventos de auditoria em memória
+type InMemoryBoletoStore struct {
+	boletos         map[string]*Boleto
+	nossoNumeros    map[string]string
+	events          []*BoletoAuditEvent
+	nossoNumeroSeq  int64
+	remessaSequence int
+	mutex           sync.RWMutex
+}
+
+// NewInMemoryBoletoStore cria um novo armazenamento em memória
+func NewInMemoryBoletoStore() *InMemoryBoletoStore {
+	return &InMemoryBoletoStore{
+		boletos:      make(map[string]*Boleto),
+		nossoNumeros: make(map[string]string),
+	}
+}
+
+// NextNossoNumero reserva o próximo nosso número com 11 dígitos
+func (s *InMemoryBoletoStore) NextNossoNumero(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nossoNumeroSeq++
+	return fmt.Sprintf("%011d", s.nossoNumeroSeq), nil
+}
+
+// NextRemessaSequence reserva o próximo número sequencial de remessa
+func (s *InMemoryBoletoStore) NextRemessaSequence(ctx context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.remessaSequence++
+	return s.remessaSequence, nil
+}
+
+// SaveBoleto grava uma cópia do boleto
+func (s *InMemoryBoletoStore) SaveBoleto(ctx context.Context, boleto *Boleto) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *boleto
+	key := boleto.TenantID + "/" + boleto.BoletoID
+	s.boletos[key] = &copied
+	s.nossoNumeros[boleto.NossoNumero] = key
+	return nil
+}
+
+// GetBoleto retorna o boleto do tenant
+func (s *InMemoryBoletoStore) GetBoleto(ctx context.Context, tenantID, boletoID string) (*Boleto, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	boleto, ok := s.boletos[tenantID+"/"+boletoID]
+	if !ok {
+		return nil, ErrBoletoNotFound
+	}
+	copied := *boleto
+	return &copied, nil
+}
+
+// GetBoletoByNossoNumero retorna o boleto com o nosso número indicado
+func (s *InMemoryBoletoStore) GetBoletoByNossoNumero(ctx context.Context, nossoNumero string) (*Boleto, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	boleto, ok := s.boletos[s.nossoNumeros[nossoNumero]]
+	if !ok {
+		return nil, ErrBoletoNotFound
+	}
+	copied := *boleto
+	return &copied, nil
+}
+
+// ListBoletosByStatus retorna cópias dos boletos no estado indicado, por ordem de vencimento
+func (s *InMemoryBoletoStore) ListBoletosByStatus(ctx context.Context, status BoletoStatus) ([]*Boleto, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var boletos []*Boleto
+	for _, boleto := range s.boletos {
+		if boleto.Status == status {
+			copied := *boleto
+			boletos = append(boletos, &copied)
+		}
+	}
+	sort.Slice(boletos, func(i, j int) bool {
+		return boletos[i].DueDate.Before(boletos[j].DueDate)
+	})
+	return boletos, nil
+}
+
+// SaveAuditEvent grava uma cópia do evento de auditoria
+func (s *InMemoryBoletoStore) SaveAuditEvent(ctx context.Context, event *BoletoAuditEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *event
+	s.events = append(s.events, &copied)
+	return nil
+}
+
+// ListAuditEvents retorna os eventos do tenant entre from (inclusive) e to (exclusive)
+func (s *InMemoryBoletoStore) ListAuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*BoletoAuditEvent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var events []*BoletoAuditEvent
+	for _, event := range s.events {
+		if event.TenantID != tenantID || event.OccurredAt.Before(from) || !event.OccurredAt.Before(to) {
+			continue
+		}
+		copied := *event
+		events = append(events, &copied)
+	}
+	return events, nil
+}
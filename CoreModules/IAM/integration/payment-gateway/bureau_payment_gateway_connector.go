@@ -79,6 +79,7 @@ type VerificationRules struct {
 	InternationalTransactions   RuleSet  `json:"international_transactions"`
 	RecurringTransactions       RuleSet  `json:"recurring_transactions"`
 	HighRiskCategories          RuleSet  `json:"high_risk_categories"`
+	UntrustedDevices            RuleSet  `json:"untrusted_devices"`
 }
 
 // RuleSet define um conjunto de regras para verificação
@@ -99,6 +100,30 @@ type BureauPaymentGatewayConnector struct {
 	verifier          *cv.CrossVerificationOrchestrator
 	transactionCache  sync.Map
 	challengeManager  *ChallengeManager
+	merchants         *MerchantService
+	async             *AsyncPaymentProcessor
+	devices           *DeviceTrustService
+	segmentLimits     *SegmentLimitService
+	dailySummaries    *DailySummaryService
+	costs             *CostAccountingService
+	networkTokens     *NetworkTokenService
+	acquirerRouting   *AcquirerRoutingService
+	stepUp            *StepUpService
+	riskEngine        *RiskEngine
+	compliance        *ComplianceService
+	webhooks          *WebhookDeliveryService
+	remittances       *RemittanceService
+	qrCodes           *QRCodeService
+	scaExemptions     *SCAExemptionService
+	instalments       *InstalmentService
+	psp               *PSPService
+	sandbox           *SandboxService
+	fraudFeedback     *FraudFeedbackService
+	featureFlags      *FeatureFlagService
+	pos               *POSService
+	storedCredentials *StoredCredentialService
+	cryptoPayments    *CryptoPaymentService
+	boletos           *BoletoConnector
 }
 
 // NewBureauPaymentGatewayConnector cria uma nova instância do conector
@@ -147,6 +172,162 @@ func (c *BureauPaymentGatewayConnector) SetVerifier(verifier *cv.CrossVerificati
 	c.logger.Info("Orquestrador de verificação cruzada configurado")
 }
 
+// SetMerchantService configura o serviço de comerciantes usado na validação de pagamentos
+func (c *BureauPaymentGatewayConnector) SetMerchantService(merchants *MerchantService) {
+	c.merchants = merchants
+	c.logger.Info("Serviço de comerciantes configurado")
+}
+
+// SetAsyncProcessor ativa o modo assíncrono, em que ProcessPayment enfileira os pagamentos
+func (c *BureauPaymentGatewayConnector) SetAsyncProcessor(processor *AsyncPaymentProcessor) {
+	c.async = processor
+	c.logger.Info("Processamento assíncrono de pagamentos configurado")
+}
+
+// SetDeviceTrustService ativa a avaliação de confiança do dispositivo nos pagamentos
+func (c *BureauPaymentGatewayConnector) SetDeviceTrustService(devices *DeviceTrustService) {
+	c.devices = devices
+	c.logger.Info("Serviço de confiança de dispositivos configurado")
+}
+
+// SetSegmentLimitService ativa a resolução de limites por segmento de cliente, que prevalece
+// sobre os limites globais da configuração
+func (c *BureauPaymentGatewayConnector) SetSegmentLimitService(segmentLimits *SegmentLimitService) {
+	c.segmentLimits = segmentLimits
+	c.logger.Info("Serviço de limites por segmento configurado")
+}
+
+// SetDailySummaryService ativa o registo dos pagamentos no livro diário usado no fecho diário
+func (c *BureauPaymentGatewayConnector) SetDailySummaryService(dailySummaries *DailySummaryService) {
+	c.dailySummaries = dailySummaries
+	c.logger.Info("Serviço de resumos diários configurado")
+}
+
+// SetCostAccountingService ativa a atribuição do custo de processamento a cada pagamento
+func (c *BureauPaymentGatewayConnector) SetCostAccountingService(costs *CostAccountingService) {
+	c.costs = costs
+	c.logger.Info("Serviço de contabilidade de custos configurado")
+}
+
+// SetNetworkTokenService ativa o uso dos tokens de rede na autorização dos pagamentos com cartão tokenizado
+func (c *BureauPaymentGatewayConnector) SetNetworkTokenService(networkTokens *NetworkTokenService) {
+	c.networkTokens = networkTokens
+	c.logger.Info("Serviço de tokenização de rede configurado")
+}
+
+// SetAcquirerRoutingService ativa o encaminhamento das autorizações aprovadas entre os adquirentes
+func (c *BureauPaymentGatewayConnector) SetAcquirerRoutingService(acquirerRouting *AcquirerRoutingService) {
+	c.acquirerRouting = acquirerRouting
+	c.logger.Info("Serviço de encaminhamento entre adquirentes configurado")
+}
+
+// SetStepUpService ativa o step-up de MFA no serviço de identidade para os pagamentos de risco médio,
+// que ficam suspensos até a conclusão do desafio em vez de receberem o desafio simulado
+func (c *BureauPaymentGatewayConnector) SetStepUpService(stepUp *StepUpService) {
+	c.stepUp = stepUp
+	c.logger.Info("Serviço de step-up de MFA configurado")
+}
+
+// SetRiskEngine ativa a avaliação dos pagamentos pelas regras de risco de cada mercado, com o registo
+// da explicação consultada pelas equipas de suporte
+func (c *BureauPaymentGatewayConnector) SetRiskEngine(riskEngine *RiskEngine) {
+	c.riskEngine = riskEngine
+	c.logger.Info("Motor de risco configurado")
+}
+
+// SetComplianceService ativa a avaliação das regras de compliance de cada mercado antes da verificação
+func (c *BureauPaymentGatewayConnector) SetComplianceService(compliance *ComplianceService) {
+	c.compliance = compliance
+	c.logger.Info("Serviço de compliance configurado")
+}
+
+// SetWebhookDeliveryService ativa a notificação do resultado dos pagamentos aos comerciantes
+func (c *BureauPaymentGatewayConnector) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	c.webhooks = webhooks
+	c.logger.Info("Serviço de entrega de webhooks configurado")
+}
+
+// SetRemittanceService ativa a execução das remessas internacionais aprovadas nos corredores configurados
+func (c *BureauPaymentGatewayConnector) SetRemittanceService(remittances *RemittanceService) {
+	c.remittances = remittances
+	c.logger.Info("Serviço de remessas internacionais configurado")
+}
+
+// SetQRCodeService ativa a geração de QR codes nos pagamentos aprovados, que ficam pendentes até à
+// confirmação do PSP
+func (c *BureauPaymentGatewayConnector) SetQRCodeService(qrCodes *QRCodeService) {
+	c.qrCodes = qrCodes
+	c.logger.Info("Serviço de QR codes configurado")
+}
+
+// SetCryptoPaymentService ativa a cotação dos pagamentos em criptoativos aprovados, que ficam pendentes
+// até o depósito atingir as confirmações exigidas pela rede
+func (c *BureauPaymentGatewayConnector) SetCryptoPaymentService(cryptoPayments *CryptoPaymentService) {
+	c.cryptoPayments = cryptoPayments
+	c.logger.Info("Serviço de pagamentos em criptoativos configurado")
+}
+
+// SetBoletoConnector ativa a emissão de boletos nos pagamentos aprovados, que ficam pendentes até à
+// liquidação comunicada no arquivo de retorno CNAB 240
+func (c *BureauPaymentGatewayConnector) SetBoletoConnector(boletos *BoletoConnector) {
+	c.boletos = boletos
+	c.logger.Info("Conector de boletos configurado")
+}
+
+// SetSCAExemptionService ativa a avaliação das isenções SCA do PSD2 nos pagamentos do mercado UE
+func (c *BureauPaymentGatewayConnector) SetSCAExemptionService(scaExemptions *SCAExemptionService) {
+	c.scaExemptions = scaExemptions
+	c.logger.Info("Serviço de isenções SCA configurado")
+}
+
+// SetInstalmentService ativa os planos de parcelamento nas compras parceladas aprovadas e a regra de
+// risco de incumprimento de parcelamentos
+func (c *BureauPaymentGatewayConnector) SetInstalmentService(instalments *InstalmentService) {
+	c.instalments = instalments
+	c.logger.Info("Serviço de parcelamento configurado")
+}
+
+// SetPSPService ativa a autorização no PSP dos pagamentos aprovados que não foram encaminhados
+// para um adquirente
+func (c *BureauPaymentGatewayConnector) SetPSPService(psp *PSPService) {
+	c.psp = psp
+	c.logger.Info("Serviço de autorização no PSP configurado")
+}
+
+// SetSandboxService ativa o modo sandbox por comerciante: os pagamentos dos comerciantes em sandbox
+// são autorizados no PSP do sandbox, sem movimento de fundos nem registo nos dados reais
+func (c *BureauPaymentGatewayConnector) SetSandboxService(sandbox *SandboxService) {
+	c.sandbox = sandbox
+	c.logger.Info("Serviço de sandbox configurado")
+}
+
+// SetFraudFeedbackService ativa as regras de risco alimentadas pelos rótulos de fraude confirmados e
+// o registo dos pagamentos avaliados na velocidade e no perfil de risco do comerciante
+func (c *BureauPaymentGatewayConnector) SetFraudFeedbackService(fraudFeedback *FraudFeedbackService) {
+	c.fraudFeedback = fraudFeedback
+	c.logger.Info("Serviço de feedback de fraude configurado")
+}
+
+// SetFeatureFlagService ativa a matriz de feature flags, que recusa os meios de pagamento desativados
+// no mercado para o nível do comerciante
+func (c *BureauPaymentGatewayConnector) SetFeatureFlagService(featureFlags *FeatureFlagService) {
+	c.featureFlags = featureFlags
+	c.logger.Info("Matriz de feature flags configurada")
+}
+
+// SetPOSService ativa a autorização das vendas EFTPOS aprovadas na rede do terminal (EMIS, SIMO)
+func (c *BureauPaymentGatewayConnector) SetPOSService(pos *POSService) {
+	c.pos = pos
+	c.logger.Info("Serviço de terminais EFTPOS configurado")
+}
+
+// SetStoredCredentialService ativa a verificação das MIT com cartão armazenado contra a credencial e o
+// ID na rede da CIT inicial
+func (c *BureauPaymentGatewayConnector) SetStoredCredentialService(storedCredentials *StoredCredentialService) {
+	c.storedCredentials = storedCredentials
+	c.logger.Info("Serviço de credenciais armazenadas configurado")
+}
+
 // ProcessPayment processa um pagamento com verificação de identidade e dados financeiros
 func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
 	ctx, span := c.tracer.StartSpan(ctx, "BureauPaymentGatewayConnector.ProcessPayment")
@@ -161,8 +342,23 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		"amount", req.Amount,
 		"currency", req.Currency)
 	
+	// Determinar o ambiente do comerciante; sem resposta do sandbox o pagamento não prossegue, para
+	// que um pagamento de teste nunca chegue aos parceiros reais
+	req.Sandbox = false
+	if c.sandbox != nil {
+		sandbox, err := c.sandbox.IsSandbox(ctx, req.TenantID, req.MerchantID)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao consultar modo sandbox do comerciante",
+				"request_id", req.RequestID,
+				"merchant_id", req.MerchantID,
+				"error", err.Error())
+			return c.createErrorResponse(req, "sandbox_erro", err.Error()), nil
+		}
+		req.Sandbox = sandbox
+	}
+	
 	// Registra métricas de início
-	c.metricsRecorder.CounterInc("payment_gateway_requests_total", map[string]string{
+	c.metricsRecorder.CounterInc(paymentMetric(req, "payment_gateway_requests_total"), map[string]string{
 		"tenant_id": req.TenantID,
 		"region": req.RegionCode,
 		"payment_method": req.PaymentMethod,
@@ -181,6 +377,35 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		req.TransactionID = fmt.Sprintf("tx-%s", uuid.New().String())
 	}
 	
+	// As MIT ficam fora da SCA: confirmar que referem uma credencial ativa e o ID na rede da CIT inicial,
+	// para que o indicador não seja usado para contornar a autenticação do titular
+	if storedCredentialMerchantInitiated(req) {
+		if c.storedCredentials == nil {
+			return c.createErrorResponse(req, "credencial_armazenada_invalida", "credenciais armazenadas não configuradas"), nil
+		}
+		if err := c.storedCredentials.VerifyMerchantInitiated(ctx, req); err != nil {
+			return c.createErrorResponse(req, "credencial_armazenada_invalida", err.Error()), nil
+		}
+	}
+	
+	// Verificar a disponibilidade do meio de pagamento no mercado para o nível do comerciante
+	if c.featureFlags != nil {
+		decision := c.featureFlags.Evaluate(ctx, req.RegionCode, req.PaymentMethod, req.MerchantID)
+		if !decision.Enabled {
+			reason := fmt.Sprintf("meio de pagamento %s desativado no mercado %s para comerciantes %s",
+				req.PaymentMethod, req.RegionCode, decision.MerchantTier)
+			if decision.Rule.Reason != "" {
+				reason += ": " + decision.Rule.Reason
+			}
+			return c.createErrorResponse(req, "meio_pagamento_desativado", reason), nil
+		}
+	}
+	
+	// No modo assíncrono o pagamento é enfileirado e processado por um worker
+	if c.async != nil && c.async.Enabled(ctx, req) {
+		return c.async.Submit(ctx, req)
+	}
+	
 	// Verificar limites de transação
 	limitResult, err := c.checkTransactionLimits(ctx, req)
 	if err != nil {
@@ -191,8 +416,128 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		return c.createErrorResponse(req, "limite_excedido", limitResult.Reason), nil
 	}
 	
+	// Verificar status, tipos de pagamento e limites do comerciante
+	if c.merchants != nil {
+		merchantResult, err := c.merchants.ValidatePayment(ctx, req)
+		if err != nil {
+			return c.createErrorResponse(req, "comerciante_erro", err.Error()), nil
+		}
+		
+		if !merchantResult.Allowed {
+			return c.createErrorResponse(req, "comerciante_invalido", merchantResult.Reason), nil
+		}
+	}
+	
+	// Avaliar a confiança do dispositivo a partir da impressão digital
+	var deviceAssessment *DeviceAssessment
+	if c.devices != nil {
+		deviceAssessment, err = c.devices.AssessDevice(ctx, req)
+		if err != nil {
+			// Falhas na avaliação não bloqueiam o pagamento; o dispositivo é tratado como desconhecido
+			c.logger.WarnWithContext(ctx, "Falha ao avaliar confiança do dispositivo",
+				"request_id", req.RequestID,
+				"error", err.Error())
+		}
+	}
+	
+	// Avaliar a isenção SCA antes das regras de risco e de compliance, que seguem a decisão
+	// Os pagamentos em sandbox não são isentos, para não alterarem a taxa de fraude nem o reporte ao adquirente
+	req.SCAExemption = nil
+	if c.scaExemptions != nil && !req.Sandbox {
+		decision, err := c.scaExemptions.Evaluate(ctx, req)
+		if err != nil {
+			// Sem decisão aplica-se o limiar de 30 EUR: a falha nunca dispensa a SCA
+			c.logger.WarnWithContext(ctx, "Falha ao avaliar isenção SCA",
+				"request_id", req.RequestID,
+				"error", err.Error())
+		}
+		req.SCAExemption = decision
+		
+		// Indicador de isenção enviado ao ACS no pedido de autenticação 3-D Secure
+		if decision != nil && decision.Outcome == SCAOutcomeExempted && req.PaymentMethod == PaymentMethodCard {
+			if req.ThreeDSData == nil {
+				req.ThreeDSData = make(map[string]interface{})
+			}
+			req.ThreeDSData["sca_exemption"] = decision.Exemption
+		}
+	}
+	
+	// Resumir o incumprimento de parcelamentos do usuário para a regra de risco correspondente
+	req.InstalmentDelinquency = nil
+	if c.instalments != nil && req.UserID != "" {
+		delinquency, err := c.instalments.Delinquency(ctx, req.TenantID, req.UserID)
+		if err != nil {
+			c.logger.WarnWithContext(ctx, "Falha ao consultar incumprimento de parcelamentos",
+				"request_id", req.RequestID,
+				"error", err.Error())
+		}
+		req.InstalmentDelinquency = delinquency
+	}
+	
+	// Resumir as fraudes confirmadas do usuário, do dispositivo e do comerciante; os pagamentos em
+	// sandbox não usam nem alimentam o histórico real
+	req.FraudHistory = nil
+	if c.fraudFeedback != nil && !req.Sandbox {
+		history, err := c.fraudFeedback.History(ctx, req)
+		if err != nil {
+			c.logger.WarnWithContext(ctx, "Falha ao consultar histórico de fraude confirmada",
+				"request_id", req.RequestID,
+				"error", err.Error())
+		}
+		req.FraudHistory = history
+	}
+	
+	// Avaliar as regras de risco do mercado; sem a explicação registada o pagamento não prossegue
+	var riskExplanation *RiskExplanation
+	if c.riskEngine != nil {
+		riskExplanation, err = c.riskEngine.EvaluateTransaction(ctx, req)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao avaliar risco do pagamento",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			return c.createErrorResponse(req, "risco_erro", err.Error()), nil
+		}
+		
+		// Contabilizar o pagamento avaliado na velocidade e no perfil do comerciante
+		if c.fraudFeedback != nil && !req.Sandbox {
+			c.fraudFeedback.RecordTransaction(ctx, req)
+		}
+		
+		if riskExplanation.Decision == RiskDecisionRejected {
+			response := c.createErrorResponse(req, "risco_rejeitado",
+				fmt.Sprintf("Transação rejeitada pelo motor de risco (score %.2f)", riskExplanation.RiskScore))
+			response.Status = TransactionStatusDenied
+			return response, nil
+		}
+	}
+	
+	// Avaliar as regras de compliance do mercado; uma regra não conforme nega o pagamento
+	if c.compliance != nil {
+		complianceResult, err := c.compliance.Evaluate(ctx, req)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao avaliar compliance do pagamento",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			return c.createErrorResponse(req, "compliance_erro", err.Error()), nil
+		}
+		
+		if !complianceResult.Compliant {
+			response := c.createErrorResponse(req, "compliance_violacao", complianceResult.Message)
+			response.Status = TransactionStatusDenied
+			return response, nil
+		}
+	}
+	
 	// Determinar o nível de verificação necessário
-	verificationLevel, extraChecks := c.determineVerificationLevel(ctx, req)
+	verificationLevel, extraChecks := c.determineVerificationLevel(ctx, req, deviceAssessment)
+	
+	// Transações em revisão pelo motor de risco exigem verificação reforçada
+	if riskExplanation != nil && riskExplanation.Decision == RiskDecisionReview {
+		verificationLevel = getHighestVerificationLevel(verificationLevel, VerificationLevelAdvanced)
+		extraChecks = append(extraChecks, "risk_review")
+	}
 	
 	c.logger.InfoWithContext(ctx, "Nível de verificação determinado",
 		"transaction_id", req.TransactionID,
@@ -222,6 +567,21 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		Timestamp:         time.Now(),
 	}
 	
+	// Alimentar o motor de risco com o resultado da avaliação do dispositivo
+	if deviceAssessment != nil {
+		verificationReq.ContextData["device_trust_score"] = deviceAssessment.TrustScore
+		verificationReq.ContextData["device_trust_level"] = deviceAssessment.TrustLevel
+		verificationReq.ContextData["device_known"] = deviceAssessment.KnownDevice
+		verificationReq.DeviceData.DeviceFingerprint = req.DeviceInfo.DeviceFingerprint
+		verificationReq.DeviceData.TrustedDevice = deviceAssessment.Trusted
+		verificationReq.DeviceData.AnomalyScore = 100 - deviceAssessment.TrustScore
+	}
+	
+	if riskExplanation != nil {
+		verificationReq.ContextData["risk_score"] = riskExplanation.RiskScore
+		verificationReq.ContextData["risk_rules"] = riskExplanation.RuleIDs()
+	}
+	
 	// Executar verificação cruzada
 	verificationResp, err := c.performCrossVerification(ctx, req, verificationReq)
 	if err != nil {
@@ -231,7 +591,7 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 			"error", err.Error())
 		
 		// Registrar falha na métrica
-		c.metricsRecorder.CounterInc("payment_verification_errors", map[string]string{
+		c.metricsRecorder.CounterInc(paymentMetric(req, "payment_verification_errors"), map[string]string{
 			"region": req.RegionCode,
 			"error_type": "verification_failure",
 		})
@@ -240,7 +600,7 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 	}
 	
 	// Processar resultado da verificação
-	response, err := c.processVerificationResult(ctx, req, verificationResp)
+	response, err := c.processVerificationResult(ctx, req, verificationResp, deviceAssessment)
 	if err != nil {
 		c.logger.ErrorWithContext(ctx, "Erro ao processar resultado da verificação",
 			"request_id", req.RequestID,
@@ -250,12 +610,387 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		return c.createErrorResponse(req, "processamento_erro", fmt.Sprintf("Erro ao processar resultado: %s", err.Error())), nil
 	}
 	
+	// Utilização do pagamento para a atribuição de custos: comissão do PSP, verificação cruzada e consulta ao bureau
+	_, bureauConsulted := verificationResp.VerificationResults[cv.CategoryFinancial]
+	usage := CostUsage{
+		Screened:          true,
+		VerificationLevel: verificationLevel,
+		ExtraChecks:       len(extraChecks),
+		BureauConsulted:   bureauConsulted,
+	}
+	
+	// Pagamento de risco médio suspenso até o usuário concluir o desafio de MFA no serviço de identidade
+	if c.stepUp != nil && response.StatusCode == StepUpRequiredStatusCode {
+		if err := c.stepUp.Begin(ctx, req, response, deviceAssessment, usage); err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao pedir step-up de MFA ao serviço de identidade",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// Sem desafio o pagamento não pode ser retomado; a resposta desafiada em cache é descartada
+			c.transactionCache.Delete(req.RequestID)
+			errorCode := "step_up_erro"
+			if errors.Is(err, ErrStepUpUnavailable) {
+				errorCode = "step_up_indisponivel"
+			}
+			return c.createErrorResponse(req, errorCode, err.Error()), nil
+		}
+		
+		response.ProcessingTimeMs = time.Since(start).Milliseconds()
+		return response, nil
+	}
+	
+	return c.finalizePayment(ctx, req, response, deviceAssessment, usage, start), nil
+}
+
+// finalizePayment obtém a credencial do cartão, autoriza no adquirente e regista o resultado final do pagamento
+// É chamado no fim de ProcessPayment e na retoma ou aborto de um pagamento suspenso por step-up
+func (c *BureauPaymentGatewayConnector) finalizePayment(ctx context.Context, req *PaymentRequest, response *PaymentResponse, deviceAssessment *DeviceAssessment, usage CostUsage, start time.Time) *PaymentResponse {
+	// Obter a credencial do cartão para a autorização: token de rede com criptograma ou, na sua falta, token do cofre
+	if c.networkTokens != nil && !req.Sandbox && req.CardTokenID != "" && response.Status == TransactionStatusApproved {
+		credential, err := c.networkTokens.AuthorizationCredential(ctx, req)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao obter credencial do cartão",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"card_token_id", req.CardTokenID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não pode ser reutilizada sem credencial
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "credencial_cartao_erro", err.Error())
+		}
+		response.CardCredential = credential
+	}
+	
+	// Autorizar no adquirente escolhido pela estratégia de encaminhamento, com cascata nas recusas
+	// transitórias; as vendas EFTPOS são autorizadas na rede do terminal
+	if c.acquirerRouting != nil && !req.Sandbox && req.PaymentMethod != PaymentMethodEFTPOS && response.Status == TransactionStatusApproved {
+		decision, result, err := c.acquirerRouting.Route(ctx, req, response.CardCredential)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Nenhum adquirente autorizou o pagamento",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não corresponde a nenhuma autorização do adquirente
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "adquirente_indisponivel", err.Error())
+		}
+		if decision != nil {
+			response.AcquirerID = decision.AcquirerID
+			if result.Approved {
+				if result.ApprovalCode != "" {
+					response.ApprovalCode = result.ApprovalCode
+				}
+				if result.AcquirerReference != "" {
+					response.AuthorizationID = result.AcquirerReference
+				}
+			} else {
+				response.Status = TransactionStatusDenied
+				response.StatusDescription = fmt.Sprintf("Transação recusada pelo adquirente (código %s)", result.ResponseCode)
+				response.StatusCode = "acquirer_declined"
+				response.ApprovalCode = ""
+				response.AuthorizationID = ""
+			}
+		}
+	}
+	
+	// Autorizar no PSP os pagamentos aprovados que nenhum adquirente autorizou; os pagamentos em
+	// sandbox são autorizados no PSP do sandbox
+	psp := c.psp
+	if req.Sandbox {
+		psp = c.sandbox.PSP()
+	}
+	if psp != nil && pspPaymentMethods[req.PaymentMethod] && response.Status == TransactionStatusApproved && response.AcquirerID == "" {
+		authorization, err := psp.Authorize(ctx, req)
+		var decline *PSPDeclineError
+		var challenge *PSPChallengeError
+		switch {
+		case errors.As(err, &decline):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "psp_recusado"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]interface{})
+			}
+			response.Metadata["psp_response_code"] = decline.Code
+		case errors.As(err, &challenge):
+			// O pagamento é reenviado com o resultado da autenticação depois do desafio
+			c.transactionCache.Delete(req.RequestID)
+			response.Status = TransactionStatusChallenged
+			response.StatusDescription = "O emissor exige autenticação 3DS"
+			response.StatusCode = "psp_desafio_3ds"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+			response.ChallengeRequired = true
+			response.ChallengeDetails = &ChallengeDetails{
+				ChallengeID:     challenge.Challenge.ThreeDSServerTransID,
+				ChallengeType:   "3ds",
+				ChallengeMethod: "redirect",
+				Instructions:    "Redirecionar o pagador para o ACS do emissor e reenviar o pagamento com o resultado da autenticação",
+				VerificationURL: challenge.Challenge.ACSURL,
+			}
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao autorizar pagamento no PSP",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não corresponde a nenhuma autorização do PSP
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "psp_indisponivel", err.Error())
+		case authorization.Status == PSPStatusPending:
+			// O desfecho chega pelo webhook do PSP, que notifica o comerciante
+			response.Status = TransactionStatusPending
+			response.StatusCode = "psp_pendente"
+			response.StatusDescription = "Autorização pendente de confirmação do PSP"
+			response.ApprovalCode = ""
+			response.AuthorizationID = authorization.Reference
+		default:
+			response.AuthorizationID = authorization.Reference
+			
+			// ID na rede da autorização, referido pelas MIT seguintes com o cartão armazenado
+			if authorization.NetworkTransactionID != "" {
+				if response.Metadata == nil {
+					response.Metadata = make(map[string]interface{})
+				}
+				response.Metadata["network_transaction_id"] = authorization.NetworkTransactionID
+			}
+		}
+	}
+	
+	// Executar o débito, o câmbio e o crédito ao beneficiário das remessas aprovadas
+	if c.remittances != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodRemittance && response.Status == TransactionStatusApproved {
+		remittance, err := c.remittances.Execute(ctx, req)
+		switch {
+		case errors.Is(err, ErrRemittanceRejected):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "remessa_recusada"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao executar remessa",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não corresponde a nenhum crédito ao beneficiário
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "remessa_falhou", err.Error())
+		default:
+			if credit := remittance.CreditLeg(); credit != nil {
+				response.AuthorizationID = credit.Reference
+			}
+		}
+	}
+	
+	// Gerar o QR code dos pagamentos aprovados; o pagamento só é concluído na confirmação do PSP
+	if c.qrCodes != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodQRCode && response.Status == TransactionStatusApproved {
+		code, err := c.qrCodes.Generate(ctx, req)
+		if err != nil {
+			c.logger.ErrorWithContext(ctx, "Erro ao gerar QR code",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não tem QR code para o pagador
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "qr_code_erro", err.Error())
+		}
+		response.Status = TransactionStatusPending
+		response.StatusCode = "qr_code_gerado"
+		response.StatusDescription = "Pagamento pendente da leitura do QR code"
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["qr_code_id"] = code.ID
+		response.Metadata["qr_code_format"] = code.Format
+		response.Metadata["qr_code_payload"] = code.Payload
+		response.Metadata["qr_code_expires_at"] = code.ExpiresAt
+	}
+	
+	// Bloquear a cotação dos pagamentos em criptoativos aprovados; o pagamento só é concluído quando o
+	// depósito atinge as confirmações exigidas pela rede
+	if c.cryptoPayments != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodCrypto && response.Status == TransactionStatusApproved {
+		payment, err := c.cryptoPayments.Create(ctx, req)
+		switch {
+		case errors.Is(err, ErrCryptoPaymentRejected):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "cripto_recusado"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao bloquear cotação em criptoativos",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não tem endereço de depósito para o pagador
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "cripto_erro", err.Error())
+		default:
+			response.Status = TransactionStatusPending
+			response.StatusCode = "cripto_aguarda_deposito"
+			response.StatusDescription = "Pagamento pendente do depósito em criptoativos"
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]interface{})
+			}
+			response.Metadata["crypto_payment_id"] = payment.ID
+			response.Metadata["crypto_asset"] = payment.Asset
+			response.Metadata["crypto_amount"] = payment.CryptoAmount
+			response.Metadata["crypto_deposit_address"] = payment.DepositAddress
+			response.Metadata["crypto_expires_at"] = payment.ExpiresAt
+			response.Metadata["travel_rule_status"] = payment.TravelRule.Status
+		}
+	}
+	
+	// Emitir e registrar o boleto dos pagamentos aprovados; o pagamento só é concluído com a liquidação
+	// comunicada pelo banco no arquivo de retorno
+	if c.boletos != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodBoleto && response.Status == TransactionStatusApproved {
+		boleto, err := c.boletos.IssueForPayment(ctx, req)
+		switch {
+		case errors.Is(err, ErrBoletoInvalidRequest):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "boleto_recusado"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao emitir boleto",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não tem boleto para o pagador
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "boleto_erro", err.Error())
+		default:
+			response.Status = TransactionStatusPending
+			response.StatusCode = "boleto_emitido"
+			response.StatusDescription = "Pagamento pendente da liquidação do boleto"
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]interface{})
+			}
+			response.Metadata["boleto_id"] = boleto.BoletoID
+			response.Metadata["boleto_nosso_numero"] = boleto.NossoNumero
+			response.Metadata["boleto_barcode"] = boleto.Barcode
+			response.Metadata["boleto_linha_digitavel"] = boleto.LinhaDigitavel
+			response.Metadata["boleto_due_date"] = boleto.DueDate
+			response.Metadata["boleto_status"] = boleto.Status
+		}
+	}
+	
+	// Criar o plano das compras parceladas aprovadas e capturar a primeira parcela
+	if c.instalments != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodInstalment && response.Status == TransactionStatusApproved {
+		plan, reference, err := c.instalments.Execute(ctx, req)
+		switch {
+		case errors.Is(err, ErrInstalmentRejected):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "parcelamento_recusado"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case errors.Is(err, ErrInstalmentCaptureDeclined):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "parcela_recusada"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao criar plano de parcelamento",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não corresponde a nenhuma parcela capturada
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "parcelamento_erro", err.Error())
+		default:
+			response.AuthorizationID = reference
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]interface{})
+			}
+			response.Metadata["instalment_plan_id"] = plan.ID
+			response.Metadata["instalment_count"] = plan.Count
+			response.Metadata["instalment_amount"] = plan.Instalments[0].Amount
+			response.Metadata["instalment_annual_rate"] = plan.AnnualRate
+		}
+	}
+	
+	// Autorizar as vendas EFTPOS aprovadas na rede do terminal ou offline dentro dos limites do terminal
+	if c.pos != nil && !req.Sandbox && req.PaymentMethod == PaymentMethodEFTPOS && response.Status == TransactionStatusApproved {
+		authorization, err := c.pos.Authorize(ctx, req)
+		switch {
+		case errors.Is(err, ErrPOSDeclined):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "pos_recusada"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case errors.Is(err, ErrPOSOfflineLimitExceeded):
+			response.Status = TransactionStatusDenied
+			response.StatusDescription = err.Error()
+			response.StatusCode = "pos_limite_offline"
+			response.ApprovalCode = ""
+			response.AuthorizationID = ""
+		case err != nil:
+			c.logger.ErrorWithContext(ctx, "Erro ao autorizar venda EFTPOS",
+				"request_id", req.RequestID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			
+			// A resposta aprovada já em cache não corresponde a nenhuma autorização da rede
+			c.transactionCache.Delete(req.RequestID)
+			return c.createErrorResponse(req, "pos_erro", err.Error())
+		default:
+			response.AuthorizationID = authorization.RRN
+			response.ApprovalCode = authorization.AuthCode
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]interface{})
+			}
+			response.Metadata["pos_offline"] = authorization.Offline
+			response.Metadata["pos_network"] = authorization.Network
+			response.Metadata["pos_batch_number"] = authorization.BatchNumber
+		}
+	}
+	
+	// Contabilizar o pagamento na taxa de fraude da isenção TRA e na lista de confiança do pagador
+	if c.scaExemptions != nil && req.SCAExemption != nil {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["sca_exemption"] = req.SCAExemption.Exemption
+		response.Metadata["sca_outcome"] = req.SCAExemption.Outcome
+		
+		if response.Status == TransactionStatusApproved {
+			if err := c.scaExemptions.RecordCompleted(ctx, req); err != nil {
+				c.logger.WarnWithContext(ctx, "Falha ao registar pagamento nas isenções SCA",
+					"request_id", req.RequestID,
+					"transaction_id", req.TransactionID,
+					"error", err.Error())
+			}
+		}
+	}
+	
+	// Indicar ao comerciante que o pagamento não movimentou fundos
+	if req.Sandbox {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["sandbox"] = true
+	}
+	
 	// Calcular tempo total de processamento
 	totalProcessingTime := time.Since(start).Milliseconds()
 	response.ProcessingTimeMs = totalProcessingTime
 	
 	// Registrar métricas de desempenho
-	c.metricsRecorder.HistogramObserve("payment_processing_time_ms", float64(totalProcessingTime), map[string]string{
+	c.metricsRecorder.HistogramObserve(paymentMetric(req, "payment_processing_time_ms"), float64(totalProcessingTime), map[string]string{
 		"region": req.RegionCode,
 		"status": response.Status,
 	})
@@ -266,4 +1001,34 @@ func (c *BureauPaymentGatewayConnector) ProcessPayment(ctx context.Context, req
 		"status", response.Status,
 		"processing_time_ms", totalProcessingTime)
 	
-	return response, nil
\ No newline at end of file
+	// Os pagamentos em sandbox não contam para os limites e métricas do comerciante, o histórico
+	// do dispositivo, o fecho diário nem os custos
+	if !req.Sandbox {
+		// Atualizar métricas de risco do comerciante
+		if c.merchants != nil {
+			c.merchants.RecordTransactionOutcome(ctx, req, response)
+		}
+		
+		// Atualizar o histórico de pagamentos do dispositivo
+		if c.devices != nil {
+			c.devices.RecordPaymentOutcome(ctx, req, response, deviceAssessment)
+		}
+		
+		// Registar o pagamento no livro diário do fecho
+		if c.dailySummaries != nil {
+			c.dailySummaries.RecordPaymentOutcome(ctx, req, response)
+		}
+		
+		// Atribuir o custo do processamento: comissão do PSP, verificação cruzada e consulta ao bureau
+		if c.costs != nil {
+			c.costs.RecordPaymentCost(ctx, req, response, usage)
+		}
+	}
+	
+	// Notificar o comerciante do resultado do pagamento
+	if c.webhooks != nil {
+		c.webhooks.NotifyTransaction(ctx, req, response)
+	}
+	
+	return response
+}
@@ -13,6 +13,11 @@ import (
 
 // Continuação da implementação do BureauPaymentGatewayConnector
 
+// CheckLimits verifica os limites de transação do pedido sem o processar, com as regras do processamento
+func (c *BureauPaymentGatewayConnector) CheckLimits(ctx context.Context, req *PaymentRequest) (*LimitCheckResult, error) {
+	return c.checkTransactionLimits(ctx, req)
+}
+
 // Verifica os limites de transação
 func (c *BureauPaymentGatewayConnector) checkTransactionLimits(ctx context.Context, req *PaymentRequest) (*LimitCheckResult, error) {
 	ctx, span := c.tracer.StartSpan(ctx, "checkTransactionLimits")
@@ -24,23 +29,35 @@ func (c *BureauPaymentGatewayConnector) checkTransactionLimits(ctx context.Conte
 	}
 	
 	// Recuperar limites aplicáveis com base em tipo de pagamento e região
-	var limitKey string
-	if req.HighRiskCategory {
-		limitKey = "high_risk"
-	} else if req.InternationalPayment {
-		limitKey = "international"
-	} else if req.RecurringPayment {
-		limitKey = "recurring"
-	} else {
-		limitKey = "standard"
-	}
+	limitKey := limitPaymentType(req)
 	
 	// Adicionar região ao limitKey se existirem configurações específicas
 	regionLimitKey := limitKey + "_" + req.RegionCode
 	
-	// Verificar limites aplicáveis
+	// Verificar limites aplicáveis: matriz do segmento do cliente, depois limites globais
 	var limit TransactionLimit
-	if l, ok := c.config.TransactionLimits[regionLimitKey]; ok {
+	var resolved *ResolvedLimit
+	if c.segmentLimits != nil {
+		var err error
+		resolved, err = c.segmentLimits.Resolve(ctx, LimitResolutionRequest{
+			TenantID:         req.TenantID,
+			PaymentType:      limitKey,
+			CustomerTier:     req.CustomerTier,
+			MerchantCategory: req.MerchantCategory,
+			Market:           req.RegionCode,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	if resolved != nil {
+		limit = resolved.Limit
+		c.logger.DebugWithContext(ctx, "Limite resolvido pela matriz do segmento",
+			"request_id", req.RequestID,
+			"rule_id", resolved.RuleID,
+			"matrix_version", resolved.MatrixVersion)
+	} else if l, ok := c.config.TransactionLimits[regionLimitKey]; ok {
 		limit = l
 	} else if l, ok := c.config.TransactionLimits[limitKey]; ok {
 		limit = l
@@ -79,7 +96,7 @@ func (c *BureauPaymentGatewayConnector) checkTransactionLimits(ctx context.Conte
 }
 
 // Determina o nível de verificação necessário para a transação
-func (c *BureauPaymentGatewayConnector) determineVerificationLevel(ctx context.Context, req *PaymentRequest) (string, []string) {
+func (c *BureauPaymentGatewayConnector) determineVerificationLevel(ctx context.Context, req *PaymentRequest, device *DeviceAssessment) (string, []string) {
 	ctx, span := c.tracer.StartSpan(ctx, "determineVerificationLevel")
 	defer span.End()
 	
@@ -164,6 +181,19 @@ func (c *BureauPaymentGatewayConnector) determineVerificationLevel(ctx context.C
 			"merchant_category", req.MerchantCategory)
 	}
 	
+	// Dispositivos novos, de baixa confiança ou com confiança revogada
+	if isUntrustedDevice(device) {
+		if rules.UntrustedDevices.RequiredVerificationLevel != "" {
+			verificationLevel = getHighestVerificationLevel(verificationLevel, rules.UntrustedDevices.RequiredVerificationLevel)
+		}
+		extraChecks = append(extraChecks, rules.UntrustedDevices.ExtraVerifications...)
+		
+		c.logger.InfoWithContext(ctx, "Aplicada regra para dispositivo não confiável",
+			"request_id", req.RequestID,
+			"verification_level", verificationLevel,
+			"device_trust_level", device.TrustLevel)
+	}
+	
 	// Remover verificações duplicadas
 	extraChecks = removeDuplicates(extraChecks)
 	
@@ -194,7 +224,7 @@ func (c *BureauPaymentGatewayConnector) performCrossVerification(ctx context.Con
 		return nil, err
 	}
 	
-	c.metricsRecorder.HistogramObserve("payment_verification_trust_score", float64(verificationResp.TrustScore), map[string]string{
+	c.metricsRecorder.HistogramObserve(paymentMetric(req, "payment_verification_trust_score"), float64(verificationResp.TrustScore), map[string]string{
 		"verification_level": verificationReq.VerificationLevel,
 		"region": req.RegionCode,
 	})
@@ -210,7 +240,7 @@ func (c *BureauPaymentGatewayConnector) performCrossVerification(ctx context.Con
 }
 
 // Processa o resultado da verificação e determina a ação a ser tomada
-func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Context, req *PaymentRequest, verification *cv.CredentialFinancialVerificationResponse) (*PaymentResponse, error) {
+func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Context, req *PaymentRequest, verification *cv.CredentialFinancialVerificationResponse, device *DeviceAssessment) (*PaymentResponse, error) {
 	ctx, span := c.tracer.StartSpan(ctx, "processVerificationResult")
 	defer span.End()
 	
@@ -237,11 +267,17 @@ func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Co
 	case cv.VerificationStatusPartial:
 		// Verificar se pontuação está acima do mínimo
 		if verification.TrustScore < minTrustScore {
-			// Pontuação abaixo do mínimo, exigir desafio
-			status = TransactionStatusChallenged
-			challengeRequired = true
-			statusDescription = "Verificação adicional necessária"
-			statusCode = "challenge_required"
+			if device != nil && device.SCAExemptionEligible {
+				// Dispositivo confiável dentro do limite de isenção, dispensa o desafio
+				statusDescription = "Transação aprovada com isenção por dispositivo confiável"
+				statusCode = "approved_trusted_device"
+			} else {
+				// Pontuação abaixo do mínimo, exigir desafio
+				status = TransactionStatusChallenged
+				challengeRequired = true
+				statusDescription = "Verificação adicional necessária"
+				statusCode = "challenge_required"
+			}
 		}
 	
 	case cv.VerificationStatusFailed:
@@ -257,6 +293,25 @@ func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Co
 		statusCode = "verification_error"
 	}
 	
+	// Dispositivo com confiança revogada pelo suporte exige sempre desafio
+	if status == TransactionStatusApproved && device != nil && device.TrustLevel == DeviceTrustLevelRevoked {
+		status = TransactionStatusChallenged
+		challengeRequired = true
+		statusDescription = "Verificação adicional necessária para dispositivo não confiável"
+		statusCode = "device_trust_revoked"
+	}
+	
+	// Com step-up configurado, o risco médio é verificado por MFA no serviço de identidade em vez do desafio simulado
+	riskLevel := getRiskLevelFromScore(verification.TrustScore)
+	stepUpRequired := false
+	if c.stepUp != nil && c.stepUp.Requires(riskLevel) && (status == TransactionStatusApproved || status == TransactionStatusChallenged) &&
+		!(device != nil && device.SCAExemptionEligible && device.TrustLevel != DeviceTrustLevelRevoked) {
+		status = TransactionStatusChallenged
+		stepUpRequired = true
+		statusDescription = "Verificação adicional por MFA necessária"
+		statusCode = StepUpRequiredStatusCode
+	}
+	
 	// Verificar anomalias críticas
 	if hasCriticalAnomaly(verification.DetectedAnomalies) {
 		status = TransactionStatusDenied
@@ -271,7 +326,7 @@ func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Co
 	
 	// Criar detalhes do desafio se necessário
 	var challengeDetails *ChallengeDetails
-	if challengeRequired {
+	if challengeRequired && !stepUpRequired {
 		challengeDetails = c.createChallenge(ctx, req, verification)
 	}
 	
@@ -285,10 +340,11 @@ func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Co
 		TrustLevel:         verification.TrustLevel,
 		StatusDescription:  statusDescription,
 		StatusCode:         statusCode,
-		ChallengeRequired:  challengeRequired,
+		ChallengeRequired:  challengeRequired || stepUpRequired,
 		ChallengeDetails:   challengeDetails,
 		DetectedAnomalies:  verification.DetectedAnomalies,
-		RiskLevel:          getRiskLevelFromScore(verification.TrustScore),
+		DeviceAssessment:   device,
+		RiskLevel:          riskLevel,
 		VerificationDetails: VerificationDetails{
 			VerificationID:    verification.VerificationID,
 			VerificationLevel: req.UserData.VerificationLevel,
@@ -307,7 +363,7 @@ func (c *BureauPaymentGatewayConnector) processVerificationResult(ctx context.Co
 	}
 	
 	// Registrar métricas do resultado
-	c.metricsRecorder.CounterInc("payment_transactions_total", map[string]string{
+	c.metricsRecorder.CounterInc(paymentMetric(req, "payment_transactions_total"), map[string]string{
 		"status": status,
 		"region": req.RegionCode,
 		"payment_method": req.PaymentMethod,
@@ -437,6 +493,18 @@ func isNewAccount(req *PaymentRequest) bool {
 	return req.UserData.AccountCreated.After(thirtyDaysAgo)
 }
 
+// Verifica se o dispositivo do pagamento é novo, de baixa confiança ou com confiança revogada
+func isUntrustedDevice(device *DeviceAssessment) bool {
+	if device == nil {
+		return false
+	}
+	switch device.TrustLevel {
+	case DeviceTrustLevelNew, DeviceTrustLevelLow, DeviceTrustLevelRevoked:
+		return true
+	}
+	return false
+}
+
 // Verifica se a categoria do comerciante é de alto risco
 func isHighRiskMerchantCategory(category string) bool {
 	// Lista simplificada de categorias de alto risco
@@ -0,0 +1,131 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	cv "github.com/innovabizdevops/innovabiz-iam/integration/cross-verification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCategoryVerifier retorna sempre o mesmo resultado e regista o último pedido de verificação
+type fakeCategoryVerifier struct {
+	mu     sync.Mutex
+	score  int
+	status string
+	last   *cv.CredentialFinancialVerificationRequest
+}
+
+func (v *fakeCategoryVerifier) Verify(ctx context.Context, req *cv.CredentialFinancialVerificationRequest) (*cv.VerificationResult, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.last = req
+	return &cv.VerificationResult{
+		Category:       cv.CategoryIdentity,
+		Status:         v.status,
+		Score:          v.score,
+		VerifiedFields: []string{"full_name"},
+	}, nil
+}
+
+func (v *fakeCategoryVerifier) GetCategory() string { return cv.CategoryIdentity }
+
+func (v *fakeCategoryVerifier) GetWeight() int { return 1 }
+
+// lastRequest retorna o último pedido recebido pelo verificador
+func (v *fakeCategoryVerifier) lastRequest() *cv.CredentialFinancialVerificationRequest {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.last
+}
+
+// newTestConnector cria o conector com um verificador que aprova todos os pagamentos
+func newTestConnector(t *testing.T) (*BureauPaymentGatewayConnector, *fakeCategoryVerifier) {
+	t.Helper()
+
+	connector, err := NewBureauPaymentGatewayConnector(BureauPaymentGatewayConfig{})
+	require.NoError(t, err)
+
+	orchestrator, err := cv.NewCrossVerificationOrchestrator(cv.OrchestrationConfig{MinRequiredScore: 60})
+	require.NoError(t, err)
+	verifier := &fakeCategoryVerifier{score: 95, status: cv.VerificationStatusPassed}
+	orchestrator.RegisterVerifier(verifier)
+	connector.SetVerifier(orchestrator)
+
+	return connector, verifier
+}
+
+func TestProcessPaymentRiskDecisions(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        func() *PaymentRequest
+		expectedStatus string
+		expectedCode   string
+		reviewed       bool
+	}{
+		{
+			name:           "aprovado sem regras acionadas",
+			request:        func() *PaymentRequest { return testRiskRequest(RegionAngola, "AOA", 1000) },
+			expectedStatus: TransactionStatusApproved,
+		},
+		{
+			name:           "revisão exige verificação reforçada",
+			request:        func() *PaymentRequest { return testRiskRequest(RegionAngola, "AOA", 600000) },
+			expectedStatus: TransactionStatusApproved,
+			reviewed:       true,
+		},
+		{
+			name:           "rejeitado sem consultar o verificador",
+			request:        func() *PaymentRequest { return testRiskRequest(RegionAngola, "USD", 100) },
+			expectedStatus: TransactionStatusDenied,
+			expectedCode:   "risco_rejeitado",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, verifier := newTestConnector(t)
+			connector.SetRiskEngine(newTestRiskEngine(t, NewInMemoryTransactionRecordStore()))
+
+			response, err := connector.ProcessPayment(context.Background(), tt.request())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.Status)
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response.StatusCode)
+				assert.Nil(t, verifier.lastRequest())
+				return
+			}
+
+			verification := verifier.lastRequest()
+			require.NotNil(t, verification)
+			extraChecks, _ := verification.ContextData["extra_checks"].([]string)
+			assert.Equal(t, tt.reviewed, contains(extraChecks, "risk_review"))
+			if tt.reviewed {
+				assert.Equal(t, VerificationLevelAdvanced, verification.VerificationLevel)
+			}
+		})
+	}
+}
+
+func TestProcessPaymentFailsWhenRiskExplanationNotRecorded(t *testing.T) {
+	connector, verifier := newTestConnector(t)
+	connector.SetRiskEngine(newTestRiskEngine(t, failingTransactionRecordStore{}))
+
+	response, err := connector.ProcessPayment(context.Background(), testRiskRequest(RegionAngola, "AOA", 1000))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusError, response.Status)
+	assert.Equal(t, "risco_erro", response.StatusCode)
+	assert.Nil(t, verifier.lastRequest())
+}
+
+// contains indica se a lista contém o valor
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
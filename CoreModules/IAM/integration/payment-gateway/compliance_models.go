@@ -0,0 +1,64 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Origens possíveis da decisão de uma regra de compliance
+const (
+	ComplianceSourcePDP      = "pdp"         // Pacote rego avaliado no PDP
+	ComplianceSourceFallback = "go_fallback" // Regra Go usada porque o PDP falhou
+	ComplianceSourceGo       = "go"          // Regra Go usada porque não há PDP configurado
+)
+
+// DefaultCompliancePDPTimeout é o tempo máximo de uma consulta ao PDP
+const DefaultCompliancePDPTimeout = 2 * time.Second
+
+// Erros do serviço de compliance
+var (
+	ErrCompliancePolicyUndefined = errors.New("política de compliance não definida no PDP")
+)
+
+// ComplianceConfig contém configurações do serviço de compliance
+type ComplianceConfig struct {
+	// Endereço do PDP partilhado (OPA); vazio avalia apenas as regras Go
+	PDPEndpoint string        `json:"pdp_endpoint"`
+	PDPTimeout  time.Duration `json:"pdp_timeout"`
+}
+
+// ComplianceRule representa uma regra de conformidade regulatória
+type ComplianceRule struct {
+	ID           string
+	Market       string // Código do mercado ou RegionGlobal
+	Framework    string
+	Requirement  string
+	Description  string
+	Policy       string                                          // Pacote rego avaliado no PDP (vazio avalia apenas Validate)
+	Validate     func(req *PaymentRequest) (bool, string, error) // Regra Go usada como fallback do PDP
+	MandatoryFor []string                                        // Meios de pagamento aos quais se aplica
+}
+
+// ComplianceRuleOutcome é o resultado da avaliação de uma regra de compliance
+type ComplianceRuleOutcome struct {
+	RuleID     string  `json:"rule_id"`
+	Framework  string  `json:"framework"`
+	Compliant  bool    `json:"compliant"`
+	Message    string  `json:"message"`
+	Source     string  `json:"source"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// ComplianceResult é o resultado da avaliação das regras de compliance de um pagamento
+type ComplianceResult struct {
+	Compliant  bool                    `json:"compliant"`
+	FailedRule string                  `json:"failed_rule,omitempty"` // Primeira regra não conforme
+	Message    string                  `json:"message,omitempty"`
+	Outcomes   []ComplianceRuleOutcome `json:"outcomes"`
+}
+
+// complianceDecision é a decisão retornada pela regra decision de cada pacote rego
+type complianceDecision struct {
+	Compliant bool   `json:"compliant"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,79 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// compliancePDP avalia os pacotes rego de compliance (policies/payment-gateway/compliance) no PDP partilhado
+type compliancePDP struct {
+	endpoint string
+	client   *http.Client
+}
+
+// newCompliancePDP cria o cliente do PDP de compliance
+func newCompliancePDP(endpoint string, timeout time.Duration) *compliancePDP {
+	return &compliancePDP{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Evaluate consulta a decisão do pacote indicado (ex.: innovabiz.payment_gateway.compliance.psd2_sca)
+func (p *compliancePDP) Evaluate(ctx context.Context, policy string, input interface{}) (*complianceDecision, error) {
+	payload, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao serializar input do PDP: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/data/%s/decision", p.endpoint, strings.ReplaceAll(policy, ".", "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar requisição ao PDP: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao consultar PDP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PDP retornou status %d para %s", resp.StatusCode, policy)
+	}
+
+	var result struct {
+		Result *complianceDecision `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar resposta do PDP: %w", err)
+	}
+	if result.Result == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCompliancePolicyUndefined, policy)
+	}
+	return result.Result, nil
+}
+
+// complianceInput monta o documento input.transaction lido pelos pacotes rego
+func complianceInput(req *PaymentRequest) map[string]interface{} {
+	transaction := map[string]interface{}{
+		"transaction_id":  req.TransactionID,
+		"tenant_id":       req.TenantID,
+		"merchant_id":     req.MerchantID,
+		"payment_type":    req.PaymentMethod,
+		"amount":          req.Amount,
+		"currency":        req.Currency,
+		"mfa_level":       req.MFALevel,
+		"market":          req.RegionCode,
+		"payment_details": req.PaymentDetails,
+		"metadata":        req.Metadata,
+		"three_ds_data":   req.ThreeDSData,
+	}
+	return map[string]interface{}{"transaction": transaction}
+}
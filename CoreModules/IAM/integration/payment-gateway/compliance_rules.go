@@ -0,0 +1,97 @@
+package paymentgateway
+
+// defaultComplianceRules retorna as regras de compliance de cada mercado e as regras globais
+// Cada regra Go replica o pacote rego correspondente e é usada quando o PDP não responde
+func defaultComplianceRules() []ComplianceRule {
+	return []ComplianceRule{
+		{
+			ID:           "bna_foreign_exchange",
+			Policy:       "innovabiz.payment_gateway.compliance.bna_foreign_exchange",
+			Market:       RegionAngola,
+			Framework:    "BNA",
+			Requirement:  "Controle de câmbio para transações internacionais",
+			Description:  "Verificar autorização de câmbio para transações em moeda estrangeira",
+			MandatoryFor: []string{PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodDigitalWallet},
+			Validate: func(req *PaymentRequest) (bool, string, error) {
+				if req.Currency != "AOA" {
+					if _, exists := req.Metadata["exchange_authorization"]; !exists {
+						return false, "Falta autorização de câmbio BNA", nil
+					}
+				}
+				return true, "Compliance de câmbio BNA verificado", nil
+			},
+		},
+		{
+			ID:           "bacen_pix",
+			Policy:       "innovabiz.payment_gateway.compliance.bacen_pix",
+			Market:       RegionBrazil,
+			Framework:    "BACEN",
+			Requirement:  "Integração com PIX para pagamentos instantâneos",
+			Description:  "Verificar conformidade com requisitos PIX para transações instantâneas",
+			MandatoryFor: []string{PaymentMethodPIX},
+			Validate: func(req *PaymentRequest) (bool, string, error) {
+				if _, exists := req.PaymentDetails["pix_key_type"]; !exists {
+					return false, "Tipo de chave PIX não especificado", nil
+				}
+				if _, exists := req.PaymentDetails["pix_key"]; !exists {
+					return false, "Chave PIX não especificada", nil
+				}
+				return true, "Compliance PIX verificado", nil
+			},
+		},
+		{
+			ID:           "psd2_sca",
+			Policy:       "innovabiz.payment_gateway.compliance.psd2_sca",
+			Market:       RegionEU,
+			Framework:    "PSD2",
+			Requirement:  "Strong Customer Authentication (SCA)",
+			Description:  "Verificar aplicação de autenticação forte para transações acima de 30 EUR",
+			MandatoryFor: []string{PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodDigitalWallet},
+			Validate: func(req *PaymentRequest) (bool, string, error) {
+				if !scaRequired(req) {
+					if req.SCAExemption != nil {
+						return true, "Isenção SCA " + req.SCAExemption.Exemption + " aplicada", nil
+					}
+					return true, "Compliance SCA verificado", nil
+				}
+				if req.MFALevel != "high" {
+					return false, "SCA requerido para transação acima de 30 EUR", nil
+				}
+				if req.PaymentMethod == PaymentMethodCard {
+					if _, exists := req.ThreeDSData["authentication_status"]; !exists {
+						return false, "Dados 3D Secure requeridos por PSD2", nil
+					}
+				}
+				return true, "Compliance SCA verificado", nil
+			},
+		},
+		{
+			ID:           "pci_dss",
+			Policy:       "innovabiz.payment_gateway.compliance.pci_dss",
+			Market:       RegionGlobal,
+			Framework:    "PCI DSS",
+			Requirement:  "Proteção de dados de cartão",
+			Description:  "Verificar aplicação de requisitos PCI DSS para dados de cartão",
+			MandatoryFor: []string{PaymentMethodCard},
+			Validate: func(req *PaymentRequest) (bool, string, error) {
+				cardData, exists := req.PaymentDetails["card"]
+				if !exists {
+					return true, "Compliance PCI DSS verificado", nil
+				}
+				cardDetails, ok := cardData.(map[string]interface{})
+				if !ok {
+					return false, "Formato inválido de dados do cartão", nil
+				}
+				if _, exists := cardDetails["full_number"]; exists {
+					return false, "Número completo do cartão não deveria estar presente", nil
+				}
+				_, hasToken := cardDetails["token"]
+				_, hasTruncatedPAN := cardDetails["truncated_pan"]
+				if !hasToken && !hasTruncatedPAN {
+					return false, "Token ou PAN truncado requerido por PCI DSS", nil
+				}
+				return true, "Compliance PCI DSS verificado", nil
+			},
+		},
+	}
+}
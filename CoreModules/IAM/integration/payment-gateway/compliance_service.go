@@ -0,0 +1,177 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+	"github.com/innovabizdevops/innovabiz-iam/src/rules"
+)
+
+// ComplianceService avalia as regras de compliance de cada mercado no PDP partilhado e recorre às
+// regras Go equivalentes quando o PDP não está configurado ou não responde. As regras são registadas
+// no motor de regras partilhado com o Bureau de Crédito, que as filtra por mercado e meio de pagamento
+type ComplianceService struct {
+	config     ComplianceConfig
+	engine     *rules.Engine[*PaymentRequest]
+	frameworks map[string]string // Framework de cada regra registada
+	pdp        *compliancePDP
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewComplianceService cria o serviço de compliance com as regras de cada mercado
+func NewComplianceService(config ComplianceConfig) (*ComplianceService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-compliance",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.PDPTimeout <= 0 {
+		config.PDPTimeout = DefaultCompliancePDPTimeout
+	}
+
+	service := &ComplianceService{
+		config:          config,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+	if config.PDPEndpoint != "" {
+		service.pdp = newCompliancePDP(config.PDPEndpoint, config.PDPTimeout)
+	}
+	if err := service.registerRules(defaultComplianceRules()); err != nil {
+		return nil, err
+	}
+
+	service.logger.Info("Serviço de compliance inicializado",
+		"total_rules", len(service.frameworks),
+		"pdp_enabled", service.pdp != nil)
+	return service, nil
+}
+
+// Evaluate aplica as regras globais e as do mercado exigidas para o meio de pagamento
+// Todas as regras aplicáveis são avaliadas; a primeira não conforme é reportada em FailedRule
+func (s *ComplianceService) Evaluate(ctx context.Context, req *PaymentRequest) (*ComplianceResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "ComplianceService.Evaluate")
+	defer span.End()
+
+	result := &ComplianceResult{
+		Compliant: true,
+		Outcomes:  make([]ComplianceRuleOutcome, 0),
+	}
+
+	evaluation := s.engine.EvaluateAll(ctx, req.RegionCode, req.PaymentMethod, req)
+	for _, outcome := range evaluation.Outcomes {
+		if outcome.Err != nil {
+			span.RecordError(outcome.Err)
+			return nil, outcome.Err
+		}
+		result.Outcomes = append(result.Outcomes, ComplianceRuleOutcome{
+			RuleID:     outcome.RuleID,
+			Framework:  s.frameworks[outcome.RuleID],
+			Compliant:  outcome.Passed,
+			Message:    outcome.Message,
+			Source:     outcome.Source,
+			DurationMs: float64(outcome.Duration.Microseconds()) / 1000,
+		})
+	}
+	if failure := evaluation.Failure(); failure != nil {
+		result.Compliant = false
+		result.FailedRule = failure.RuleID
+		result.Message = failure.Message
+	}
+
+	s.logger.InfoWithContext(ctx, "Avaliação de compliance concluída",
+		"transaction_id", req.TransactionID,
+		"compliant", result.Compliant,
+		"failed_rule", result.FailedRule,
+		"evaluated_rules", len(result.Outcomes))
+
+	return result, nil
+}
+
+// registerRules substitui as regras avaliadas pelo serviço, registando-as no motor de regras partilhado
+// As regras globais (RegionGlobal) aplicam-se a todos os mercados e cada regra apenas aos meios de
+// pagamento de MandatoryFor
+func (s *ComplianceService) registerRules(complianceRules []ComplianceRule) error {
+	engine := rules.NewEngine[*PaymentRequest]("payment_gateway_compliance")
+	engine.SetObserver(s.recordRuleOutcome)
+	frameworks := make(map[string]string, len(complianceRules))
+
+	for _, rule := range complianceRules {
+		rule := rule
+		market := rule.Market
+		if market == RegionGlobal {
+			market = rules.MarketGlobal
+		}
+		if err := engine.Register(rules.Rule[*PaymentRequest]{
+			ID:          rule.ID,
+			Market:      market,
+			Description: rule.Description,
+			Framework:   []string{rule.Framework},
+			Types:       rule.MandatoryFor,
+			Applies:     func(req *PaymentRequest) bool { return len(rule.MandatoryFor) > 0 },
+			Evaluate: func(ctx context.Context, req *PaymentRequest) (rules.Decision, error) {
+				return s.evaluateRule(ctx, rule, req)
+			},
+		}); err != nil {
+			return fmt.Errorf("falha ao registar regra de compliance: %w", err)
+		}
+		frameworks[rule.ID] = rule.Framework
+	}
+
+	s.engine = engine
+	s.frameworks = frameworks
+	return nil
+}
+
+// evaluateRule avalia uma regra no PDP ou, como fallback, pela regra Go
+func (s *ComplianceService) evaluateRule(ctx context.Context, rule ComplianceRule, req *PaymentRequest) (rules.Decision, error) {
+	source := ComplianceSourceGo
+	if s.pdp != nil && rule.Policy != "" {
+		decision, err := s.pdp.Evaluate(ctx, rule.Policy, complianceInput(req))
+		if err == nil {
+			return rules.Decision{Passed: decision.Compliant, Message: decision.Message, Source: ComplianceSourcePDP}, nil
+		}
+		s.logger.WarnWithContext(ctx, "PDP indisponível, aplicando regra Go de compliance",
+			"rule_id", rule.ID,
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+		source = ComplianceSourceFallback
+	}
+
+	if rule.Validate == nil {
+		return rules.Decision{Source: source}, fmt.Errorf("regra de compliance %s sem PDP nem regra Go disponível", rule.ID)
+	}
+	compliant, message, err := rule.Validate(req)
+	if err != nil {
+		return rules.Decision{Source: source}, fmt.Errorf("falha ao avaliar regra de compliance %s: %w", rule.ID, err)
+	}
+	return rules.Decision{Passed: compliant, Message: message, Source: source}, nil
+}
+
+// recordRuleOutcome regista as métricas de cada regra avaliada: origem da decisão, resultado e latência
+func (s *ComplianceService) recordRuleOutcome(ctx context.Context, req *PaymentRequest, outcome rules.Outcome) {
+	if outcome.Err != nil {
+		return
+	}
+
+	labels := map[string]string{
+		"rule_id":   outcome.RuleID,
+		"source":    outcome.Source,
+		"compliant": fmt.Sprintf("%t", outcome.Passed),
+	}
+	s.metricsRecorder.CounterInc("payment_gateway_compliance_rule_evaluations", labels)
+	s.metricsRecorder.HistogramObserve("payment_gateway_compliance_rule_duration_ms", float64(outcome.Duration.Microseconds())/1000, labels)
+}
@@ -0,0 +1,294 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestComplianceService(t *testing.T, pdpEndpoint string) *ComplianceService {
+	t.Helper()
+
+	service, err := NewComplianceService(ComplianceConfig{PDPEndpoint: pdpEndpoint})
+	require.NoError(t, err)
+	return service
+}
+
+// newTestPDP simula o PDP: responde com a decisão de cada pacote e regista o input recebido
+func newTestPDP(t *testing.T, decisions map[string]*complianceDecision) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var policies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/data/"), "/decision")
+		policy = strings.ReplaceAll(policy, "/", ".")
+
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body.Input, "transaction")
+
+		mu.Lock()
+		policies = append(policies, policy)
+		mu.Unlock()
+
+		decision, exists := decisions[policy]
+		if !exists {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": decision})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), policies...)
+	}
+}
+
+func TestComplianceGoRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    func() *PaymentRequest
+		compliant  bool
+		failedRule string
+		evaluated  []string
+	}{
+		{
+			name:      "cartão em kwanza sem dados de cartão",
+			request:   func() *PaymentRequest { return testRiskRequest(RegionAngola, "AOA", 1000) },
+			compliant: true,
+			evaluated: []string{"bna_foreign_exchange", "pci_dss"},
+		},
+		{
+			name:       "moeda estrangeira em Angola sem autorização de câmbio",
+			request:    func() *PaymentRequest { return testRiskRequest(RegionAngola, "USD", 100) },
+			failedRule: "bna_foreign_exchange",
+			evaluated:  []string{"bna_foreign_exchange", "pci_dss"},
+		},
+		{
+			name: "moeda estrangeira em Angola com autorização de câmbio",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionAngola, "USD", 100)
+				req.Metadata = map[string]interface{}{"exchange_authorization": "BNA-123"}
+				return req
+			},
+			compliant: true,
+			evaluated: []string{"bna_foreign_exchange", "pci_dss"},
+		},
+		{
+			name: "PIX sem chave",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionBrazil, "BRL", 100)
+				req.PaymentMethod = PaymentMethodPIX
+				req.PaymentDetails = map[string]interface{}{"pix_key_type": "cpf"}
+				return req
+			},
+			failedRule: "bacen_pix",
+			evaluated:  []string{"bacen_pix"},
+		},
+		{
+			name: "PIX com tipo e chave",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionBrazil, "BRL", 100)
+				req.PaymentMethod = PaymentMethodPIX
+				req.PaymentDetails = map[string]interface{}{"pix_key_type": "cpf", "pix_key": "12345678900"}
+				return req
+			},
+			compliant: true,
+			evaluated: []string{"bacen_pix"},
+		},
+		{
+			name:      "EUR até 30 dispensa SCA",
+			request:   func() *PaymentRequest { return testRiskRequest(RegionEU, "EUR", 30) },
+			compliant: true,
+			evaluated: []string{"psd2_sca", "pci_dss"},
+		},
+		{
+			name:       "EUR acima de 30 sem MFA forte",
+			request:    func() *PaymentRequest { return testRiskRequest(RegionEU, "EUR", 31) },
+			failedRule: "psd2_sca",
+			evaluated:  []string{"psd2_sca", "pci_dss"},
+		},
+		{
+			name: "EUR acima de 30 com MFA forte sem 3D Secure",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionEU, "EUR", 100)
+				req.MFALevel = "high"
+				return req
+			},
+			failedRule: "psd2_sca",
+			evaluated:  []string{"psd2_sca", "pci_dss"},
+		},
+		{
+			name: "EUR acima de 30 com MFA forte e 3D Secure",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionEU, "EUR", 100)
+				req.MFALevel = "high"
+				req.ThreeDSData = map[string]interface{}{"authentication_status": "Y"}
+				return req
+			},
+			compliant: true,
+			evaluated: []string{"psd2_sca", "pci_dss"},
+		},
+		{
+			name: "PAN completo viola PCI DSS",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionUSA, "USD", 100)
+				req.PaymentDetails = map[string]interface{}{
+					"card": map[string]interface{}{"full_number": "4111111111111111"},
+				}
+				return req
+			},
+			failedRule: "pci_dss",
+			evaluated:  []string{"pci_dss"},
+		},
+		{
+			name: "cartão tokenizado cumpre PCI DSS",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionUSA, "USD", 100)
+				req.PaymentDetails = map[string]interface{}{
+					"card": map[string]interface{}{"token": "tok_123"},
+				}
+				return req
+			},
+			compliant: true,
+			evaluated: []string{"pci_dss"},
+		},
+		{
+			name: "transferência nos EUA não tem regras aplicáveis",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionUSA, "USD", 100)
+				req.PaymentMethod = PaymentMethodBankTransfer
+				return req
+			},
+			compliant: true,
+			evaluated: []string{},
+		},
+	}
+
+	service := newTestComplianceService(t, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Evaluate(context.Background(), tt.request())
+			require.NoError(t, err)
+			assert.Equal(t, tt.compliant, result.Compliant)
+			assert.Equal(t, tt.failedRule, result.FailedRule)
+			if !tt.compliant {
+				assert.NotEmpty(t, result.Message)
+			}
+
+			evaluated := make([]string, 0, len(result.Outcomes))
+			for _, outcome := range result.Outcomes {
+				evaluated = append(evaluated, outcome.RuleID)
+				assert.Equal(t, ComplianceSourceGo, outcome.Source)
+			}
+			assert.Equal(t, tt.evaluated, evaluated)
+		})
+	}
+}
+
+func TestComplianceUsesPDPDecision(t *testing.T) {
+	server, policies := newTestPDP(t, map[string]*complianceDecision{
+		"innovabiz.payment_gateway.compliance.psd2_sca": {Compliant: false, Message: "SCA requerido (PDP)"},
+		"innovabiz.payment_gateway.compliance.pci_dss":  {Compliant: true, Message: "PCI DSS verificado (PDP)"},
+	})
+	service := newTestComplianceService(t, server.URL)
+
+	// A regra Go aprovaria (EUR até 30); vale a decisão do PDP
+	result, err := service.Evaluate(context.Background(), testRiskRequest(RegionEU, "EUR", 10))
+	require.NoError(t, err)
+
+	assert.False(t, result.Compliant)
+	assert.Equal(t, "psd2_sca", result.FailedRule)
+	assert.Equal(t, "SCA requerido (PDP)", result.Message)
+	for _, outcome := range result.Outcomes {
+		assert.Equal(t, ComplianceSourcePDP, outcome.Source)
+	}
+	assert.Equal(t, []string{
+		"innovabiz.payment_gateway.compliance.psd2_sca",
+		"innovabiz.payment_gateway.compliance.pci_dss",
+	}, policies())
+}
+
+func TestComplianceFallsBackToGoRules(t *testing.T) {
+	t.Run("política não definida no PDP", func(t *testing.T) {
+		server, _ := newTestPDP(t, map[string]*complianceDecision{
+			"innovabiz.payment_gateway.compliance.pci_dss": {Compliant: true},
+		})
+		service := newTestComplianceService(t, server.URL)
+
+		result, err := service.Evaluate(context.Background(), testRiskRequest(RegionEU, "EUR", 100))
+		require.NoError(t, err)
+
+		assert.False(t, result.Compliant)
+		assert.Equal(t, "psd2_sca", result.FailedRule)
+		require.Len(t, result.Outcomes, 2)
+		assert.Equal(t, ComplianceSourceFallback, result.Outcomes[0].Source)
+		assert.Equal(t, ComplianceSourcePDP, result.Outcomes[1].Source)
+	})
+
+	t.Run("PDP indisponível", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(server.Close)
+		service := newTestComplianceService(t, server.URL)
+
+		result, err := service.Evaluate(context.Background(), testRiskRequest(RegionAngola, "AOA", 1000))
+		require.NoError(t, err)
+
+		assert.True(t, result.Compliant)
+		require.Len(t, result.Outcomes, 2)
+		for _, outcome := range result.Outcomes {
+			assert.Equal(t, ComplianceSourceFallback, outcome.Source)
+		}
+	})
+}
+
+func TestComplianceFailsWithoutPDPOrGoRule(t *testing.T) {
+	service := newTestComplianceService(t, "")
+	require.NoError(t, service.registerRules([]ComplianceRule{{
+		ID:           "policy_only",
+		Market:       RegionGlobal,
+		Policy:       "innovabiz.payment_gateway.compliance.policy_only",
+		MandatoryFor: []string{PaymentMethodCard},
+	}}))
+
+	_, err := service.Evaluate(context.Background(), testRiskRequest(RegionAngola, "AOA", 1000))
+	assert.Error(t, err)
+}
+
+func TestProcessPaymentComplianceDecisions(t *testing.T) {
+	t.Run("violação nega o pagamento sem consultar o verificador", func(t *testing.T) {
+		connector, verifier := newTestConnector(t)
+		connector.SetComplianceService(newTestComplianceService(t, ""))
+
+		response, err := connector.ProcessPayment(context.Background(), testRiskRequest(RegionAngola, "USD", 100))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "compliance_violacao", response.StatusCode)
+		assert.Nil(t, verifier.lastRequest())
+	})
+
+	t.Run("pagamento conforme segue para a verificação", func(t *testing.T) {
+		connector, verifier := newTestConnector(t)
+		connector.SetComplianceService(newTestComplianceService(t, ""))
+
+		response, err := connector.ProcessPayment(context.Background(), testRiskRequest(RegionAngola, "AOA", 1000))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.NotNil(t, verifier.lastRequest())
+	})
+}
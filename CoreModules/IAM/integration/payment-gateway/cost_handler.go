@@ -0,0 +1,82 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CostHandler expõe a API HTTP dos relatórios de custos de processamento
+type CostHandler struct {
+	service *CostAccountingService
+}
+
+// NewCostHandler cria uma nova instância do CostHandler
+func NewCostHandler(service *CostAccountingService) *CostHandler {
+	return &CostHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *CostHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/reports/costs", h.GetCostReport).Methods(http.MethodGet)
+	router.HandleFunc("/reports/costs/rate-cards", h.ListRateCards).Methods(http.MethodGet)
+	router.HandleFunc("/reports/costs/transactions/{transaction_id}", h.GetTransactionCost).Methods(http.MethodGet)
+}
+
+// GetCostReport retorna os custos do tenant no período (AAAA-MM-DD, datas inclusivas),
+// agregados por mercado, comerciante e moeda e opcionalmente filtrados por mercado e comerciante
+func (h *CostHandler) GetCostReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := CostReportFilter{
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		RegionCode: query.Get("region_code"),
+		MerchantID: query.Get("merchant_id"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro limit inválido")
+			return
+		}
+		filter.Limit = value
+	}
+
+	report, err := h.service.CostReport(r.Context(), r.Header.Get("X-Tenant-ID"), filter)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// ListRateCards retorna as tabelas de custos usadas na atribuição
+func (h *CostHandler) ListRateCards(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.RateCards())
+}
+
+// GetTransactionCost retorna os componentes do custo atribuído a uma transação do tenant
+func (h *CostHandler) GetTransactionCost(w http.ResponseWriter, r *http.Request) {
+	cost, err := h.service.GetTransactionCost(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["transaction_id"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cost)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *CostHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCostReportFilterInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrTransactionCostNotFound):
+		respondWithError(w, http.StatusNotFound, "transaction_cost_not_found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "cost_report_error", err.Error())
+	}
+}
@@ -0,0 +1,236 @@
+package paymentgateway
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Componentes do custo de processamento de uma transação
+const (
+	CostComponentPSP       = "psp_fee"       // Comissão estimada do PSP/adquirente
+	CostComponentScreening = "screening_fee" // Verificação cruzada de identidade e verificações adicionais
+	CostComponentBureau    = "bureau_fee"    // Consulta ao bureau de crédito
+)
+
+// Valores padrão da contabilidade de custos
+const (
+	DefaultCostReportListLimit = 500
+)
+
+// Erros da contabilidade de custos
+var (
+	ErrCostRateCardInvalid     = errors.New("tabela de custos inválida")
+	ErrCostReportFilterInvalid = errors.New("filtro do relatório de custos inválido")
+	ErrTransactionCostNotFound = errors.New("custo da transação não encontrado")
+)
+
+// CostRate define uma tarifa: valor fixo mais percentagem do montante, limitada a [MinFee, MaxFee]
+// MaxFee igual a zero não limita a tarifa
+type CostRate struct {
+	FixedFee   float64 `json:"fixed_fee"`
+	PercentFee float64 `json:"percent_fee"` // Percentagem do montante (ex.: 1.5 para 1,5%)
+	MinFee     float64 `json:"min_fee,omitempty"`
+	MaxFee     float64 `json:"max_fee,omitempty"`
+}
+
+// Apply calcula a tarifa para o montante, arredondada a 4 casas decimais
+func (r CostRate) Apply(amount float64) float64 {
+	fee := r.FixedFee + amount*r.PercentFee/100
+	if fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee > 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	return roundSummaryValue(fee, 4)
+}
+
+// validate verifica que a tarifa não tem valores negativos nem limites incoerentes
+func (r CostRate) validate() bool {
+	if r.FixedFee < 0 || r.PercentFee < 0 || r.MinFee < 0 || r.MaxFee < 0 {
+		return false
+	}
+	if math.IsNaN(r.FixedFee) || math.IsNaN(r.PercentFee) {
+		return false
+	}
+	return r.MaxFee == 0 || r.MaxFee >= r.MinFee
+}
+
+// CostRateCard é a tabela de custos de um mercado e moeda
+// Mercado ou moeda "*" correspondem a qualquer valor; a tabela mais específica prevalece
+type CostRateCard struct {
+	RateCardID string `json:"rate_card_id"`
+	RegionCode string `json:"region_code"`
+	Currency   string `json:"currency"`
+
+	// Comissão do PSP por método de pagamento; "*" aplica-se aos métodos não indicados
+	// Cobrada apenas nas transações aprovadas
+	PSPRates map[string]CostRate `json:"psp_rates"`
+
+	// Custo da verificação cruzada por nível de verificação; "*" aplica-se aos níveis não indicados
+	ScreeningRates map[string]CostRate `json:"screening_rates"`
+
+	// Custo de cada verificação adicional pedida pelas regras de verificação
+	ExtraCheckFee float64 `json:"extra_check_fee"`
+
+	// Custo de cada consulta ao bureau de crédito
+	BureauRate CostRate `json:"bureau_rate"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// Validate verifica a identificação e as tarifas da tabela
+func (c *CostRateCard) Validate() error {
+	if c.RateCardID == "" || c.RegionCode == "" || c.Currency == "" || c.ExtraCheckFee < 0 {
+		return ErrCostRateCardInvalid
+	}
+	for _, rate := range c.PSPRates {
+		if !rate.validate() {
+			return ErrCostRateCardInvalid
+		}
+	}
+	for _, rate := range c.ScreeningRates {
+		if !rate.validate() {
+			return ErrCostRateCardInvalid
+		}
+	}
+	if !c.BureauRate.validate() {
+		return ErrCostRateCardInvalid
+	}
+	return nil
+}
+
+// specificity indica quantas dimensões da tabela são explícitas, para escolher a mais específica
+func (c *CostRateCard) specificity() int {
+	specificity := 0
+	if c.RegionCode != SegmentWildcard {
+		specificity += 2
+	}
+	if c.Currency != SegmentWildcard {
+		specificity++
+	}
+	return specificity
+}
+
+// matches indica se a tabela se aplica ao mercado e à moeda
+func (c *CostRateCard) matches(regionCode, currency string) bool {
+	return (c.RegionCode == SegmentWildcard || c.RegionCode == regionCode) &&
+		(c.Currency == SegmentWildcard || c.Currency == currency)
+}
+
+// rateFor retorna a tarifa da chave ou, na sua falta, a tarifa "*"
+func rateFor(rates map[string]CostRate, key string) (CostRate, bool) {
+	if rate, ok := rates[key]; ok {
+		return rate, true
+	}
+	rate, ok := rates[SegmentWildcard]
+	return rate, ok
+}
+
+// CostUsage descreve os serviços consumidos no processamento de uma transação
+type CostUsage struct {
+	Screened          bool   `json:"screened"`           // Verificação cruzada executada
+	VerificationLevel string `json:"verification_level"` // Nível da verificação cruzada
+	ExtraChecks       int    `json:"extra_checks"`       // Verificações adicionais pedidas
+	BureauConsulted   bool   `json:"bureau_consulted"`   // Dados financeiros verificados junto do bureau
+}
+
+// CostComponent é uma parcela do custo de processamento de uma transação
+type CostComponent struct {
+	Type   string  `json:"type"` // psp_fee, screening_fee ou bureau_fee
+	Amount float64 `json:"amount"`
+	Basis  string  `json:"basis,omitempty"` // Chave da tarifa aplicada (método de pagamento ou nível de verificação)
+}
+
+// TransactionCost é o custo de processamento atribuído a uma transação
+type TransactionCost struct {
+	TransactionID string          `json:"transaction_id" db:"transaction_id"`
+	TenantID      string          `json:"tenant_id" db:"tenant_id"`
+	RegionCode    string          `json:"region_code" db:"region_code"`
+	MerchantID    string          `json:"merchant_id" db:"merchant_id"`
+	PaymentMethod string          `json:"payment_method" db:"payment_method"`
+	Status        string          `json:"status" db:"status"`
+	Amount        float64         `json:"amount" db:"amount"`
+	Currency      string          `json:"currency" db:"currency"`
+	RateCardID    string          `json:"rate_card_id" db:"rate_card_id"`
+	PSPFee        float64         `json:"psp_fee" db:"psp_fee"`
+	ScreeningFee  float64         `json:"screening_fee" db:"screening_fee"`
+	BureauFee     float64         `json:"bureau_fee" db:"bureau_fee"`
+	TotalCost     float64         `json:"total_cost" db:"total_cost"`
+	Components    []CostComponent `json:"components" db:"-"`
+	OccurredAt    time.Time       `json:"occurred_at" db:"occurred_at"`
+}
+
+// MerchantCostSummary agrega os custos de um período por tenant, mercado, comerciante e moeda
+type MerchantCostSummary struct {
+	TenantID           string  `json:"tenant_id" db:"tenant_id"`
+	RegionCode         string  `json:"region_code" db:"region_code"`
+	MerchantID         string  `json:"merchant_id" db:"merchant_id"`
+	Currency           string  `json:"currency" db:"currency"`
+	TransactionCount   int64   `json:"transaction_count" db:"transaction_count"`
+	ApprovedVolume     float64 `json:"approved_volume" db:"approved_volume"`
+	PSPFees            float64 `json:"psp_fees" db:"psp_fees"`
+	ScreeningFees      float64 `json:"screening_fees" db:"screening_fees"`
+	BureauFees         float64 `json:"bureau_fees" db:"bureau_fees"`
+	TotalCost          float64 `json:"total_cost" db:"total_cost"`
+	CostPerTransaction float64 `json:"cost_per_transaction" db:"-"`
+	CostRateBps        float64 `json:"cost_rate_bps" db:"-"` // Custo total em pontos base do volume aprovado
+}
+
+// finalize arredonda os totais e calcula os indicadores derivados
+func (s *MerchantCostSummary) finalize() {
+	s.ApprovedVolume = roundSummaryValue(s.ApprovedVolume, 2)
+	s.PSPFees = roundSummaryValue(s.PSPFees, 4)
+	s.ScreeningFees = roundSummaryValue(s.ScreeningFees, 4)
+	s.BureauFees = roundSummaryValue(s.BureauFees, 4)
+	s.TotalCost = roundSummaryValue(s.TotalCost, 4)
+	if s.TransactionCount > 0 {
+		s.CostPerTransaction = roundSummaryValue(s.TotalCost/float64(s.TransactionCount), 4)
+	}
+	if s.ApprovedVolume > 0 {
+		s.CostRateBps = roundSummaryValue(s.TotalCost/s.ApprovedVolume*10000, 2)
+	}
+}
+
+// CostReportFilter delimita o relatório de custos de um tenant (datas inclusivas, AAAA-MM-DD, em UTC)
+type CostReportFilter struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	RegionCode string `json:"region_code,omitempty"`
+	MerchantID string `json:"merchant_id,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+
+	from, to time.Time // Intervalo [from, to) derivado das datas
+}
+
+// Validate verifica as datas e o limite do filtro
+func (f *CostReportFilter) Validate() error {
+	from, err := time.Parse(SummaryDateLayout, f.From)
+	if err != nil {
+		return ErrCostReportFilterInvalid
+	}
+	to, err := time.Parse(SummaryDateLayout, f.To)
+	if err != nil || to.Before(from) {
+		return ErrCostReportFilterInvalid
+	}
+	if f.Limit <= 0 || f.Limit > DefaultCostReportListLimit {
+		f.Limit = DefaultCostReportListLimit
+	}
+	f.from, f.to = from, to.AddDate(0, 0, 1)
+	return nil
+}
+
+// CostReport é o relatório de custos de um tenant no período
+type CostReport struct {
+	TenantID    string                 `json:"tenant_id"`
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Summaries   []*MerchantCostSummary `json:"summaries"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// CostAccountingConfig contém as tabelas de custos usadas na atribuição
+type CostAccountingConfig struct {
+	RateCards []CostRateCard `json:"rate_cards"`
+}
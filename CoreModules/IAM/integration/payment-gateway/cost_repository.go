@@ -0,0 +1,134 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresCostStore implementa CostStore para PostgreSQL
+type PostgresCostStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresCostStore cria uma nova instância de PostgresCostStore
+func NewPostgresCostStore(db *sqlx.DB) *PostgresCostStore {
+	return &PostgresCostStore{db: db}
+}
+
+// dbTransactionCost é a representação de TransactionCost na base de dados
+type dbTransactionCost struct {
+	TransactionCost
+	Components []byte `db:"components"`
+}
+
+// SaveTransactionCost grava o custo da transação; um novo registo substitui o anterior
+func (r *PostgresCostStore) SaveTransactionCost(ctx context.Context, cost *TransactionCost) error {
+	components, err := json.Marshal(cost.Components)
+	if err != nil {
+		return fmt.Errorf("falha ao codificar componentes do custo: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.transaction_costs (
+			tenant_id, transaction_id, region_code, merchant_id, payment_method, status,
+			amount, currency, rate_card_id, psp_fee, screening_fee, bureau_fee, total_cost,
+			components, occurred_at
+		) VALUES (
+			:tenant_id, :transaction_id, :region_code, :merchant_id, :payment_method, :status,
+			:amount, :currency, :rate_card_id, :psp_fee, :screening_fee, :bureau_fee, :total_cost,
+			:components, :occurred_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			rate_card_id = EXCLUDED.rate_card_id,
+			psp_fee = EXCLUDED.psp_fee,
+			screening_fee = EXCLUDED.screening_fee,
+			bureau_fee = EXCLUDED.bureau_fee,
+			total_cost = EXCLUDED.total_cost,
+			components = EXCLUDED.components,
+			occurred_at = EXCLUDED.occurred_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, &dbTransactionCost{TransactionCost: *cost, Components: components}); err != nil {
+		return fmt.Errorf("falha ao gravar custo da transação: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactionCost recupera o custo de uma transação do tenant, ou nil se não existir
+func (r *PostgresCostStore) GetTransactionCost(ctx context.Context, tenantID, transactionID string) (*TransactionCost, error) {
+	var row dbTransactionCost
+	query := `
+		SELECT tenant_id, transaction_id, region_code, merchant_id, payment_method, status,
+			amount, currency, rate_card_id, psp_fee, screening_fee, bureau_fee, total_cost,
+			components, occurred_at
+		FROM payment_gateway.transaction_costs
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar custo da transação: %w", err)
+	}
+
+	cost := row.TransactionCost
+	cost.Components = []CostComponent{}
+	if len(row.Components) > 0 {
+		if err := json.Unmarshal(row.Components, &cost.Components); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar componentes do custo: %w", err)
+		}
+	}
+
+	return &cost, nil
+}
+
+// SummarizeCosts agrega os custos do tenant no intervalo do filtro por mercado, comerciante e moeda
+func (r *PostgresCostStore) SummarizeCosts(ctx context.Context, tenantID string, filter CostReportFilter) ([]*MerchantCostSummary, error) {
+	conditions := []string{"tenant_id = $1", "occurred_at >= $2", "occurred_at < $3"}
+	args := []interface{}{tenantID, filter.from, filter.to}
+
+	if filter.RegionCode != "" {
+		args = append(args, filter.RegionCode)
+		conditions = append(conditions, fmt.Sprintf("region_code = $%d", len(args)))
+	}
+	if filter.MerchantID != "" {
+		args = append(args, filter.MerchantID)
+		conditions = append(conditions, fmt.Sprintf("merchant_id = $%d", len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultCostReportListLimit
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT tenant_id, region_code, merchant_id, currency,
+			COUNT(*) AS transaction_count,
+			COALESCE(SUM(amount) FILTER (WHERE status = '%s'), 0) AS approved_volume,
+			SUM(psp_fee) AS psp_fees,
+			SUM(screening_fee) AS screening_fees,
+			SUM(bureau_fee) AS bureau_fees,
+			SUM(total_cost) AS total_cost
+		FROM payment_gateway.transaction_costs
+		WHERE %s
+		GROUP BY tenant_id, region_code, merchant_id, currency
+		ORDER BY region_code, merchant_id, currency
+		LIMIT $%d
+	`, TransactionStatusApproved, strings.Join(conditions, " AND "), len(args))
+
+	var summaries []*MerchantCostSummary
+	if err := r.db.SelectContext(ctx, &summaries, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao agregar custos das transações: %w", err)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,221 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// CostAccountingService atribui a cada transação o custo do seu processamento (comissão do PSP,
+// verificação cruzada e consulta ao bureau), segundo as tabelas de custos configuradas, e
+// produz os relatórios de custos por mercado e comerciante
+type CostAccountingService struct {
+	rateCards []CostRateCard
+	store     CostStore
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewCostAccountingService cria o serviço de contabilidade de custos
+func NewCostAccountingService(config CostAccountingConfig, store CostStore) (*CostAccountingService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-cost-accounting",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range config.RateCards {
+		card := &config.RateCards[i]
+		if err := card.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, card.RateCardID)
+		}
+		key := card.RegionCode + "\x00" + card.Currency
+		if seen[key] {
+			return nil, fmt.Errorf("%w: tabela duplicada para %s/%s", ErrCostRateCardInvalid, card.RegionCode, card.Currency)
+		}
+		seen[key] = true
+	}
+
+	// As tabelas mais específicas são avaliadas primeiro
+	rateCards := append([]CostRateCard(nil), config.RateCards...)
+	sort.SliceStable(rateCards, func(i, j int) bool {
+		return rateCards[i].specificity() > rateCards[j].specificity()
+	})
+
+	return &CostAccountingService{
+		rateCards:       rateCards,
+		store:           store,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}, nil
+}
+
+// SetClock substitui o relógio usado na data dos custos e dos relatórios
+func (s *CostAccountingService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// RateCards retorna as tabelas de custos configuradas, da mais para a menos específica
+func (s *CostAccountingService) RateCards() []CostRateCard {
+	return append([]CostRateCard(nil), s.rateCards...)
+}
+
+// Estimate calcula o custo de processamento da transação sem o registar
+// Sem tabela aplicável ao mercado e moeda, o custo é zero e a tabela fica vazia
+func (s *CostAccountingService) Estimate(req *PaymentRequest, status string, usage CostUsage) *TransactionCost {
+	cost := &TransactionCost{
+		TransactionID: req.TransactionID,
+		TenantID:      req.TenantID,
+		RegionCode:    req.RegionCode,
+		MerchantID:    req.MerchantID,
+		PaymentMethod: req.PaymentMethod,
+		Status:        status,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Components:    []CostComponent{},
+		OccurredAt:    s.now(),
+	}
+
+	card := s.rateCardFor(req.RegionCode, req.Currency)
+	if card == nil {
+		return cost
+	}
+	cost.RateCardID = card.RateCardID
+
+	// A comissão do PSP só é devida quando a transação é autorizada
+	if status == TransactionStatusApproved {
+		if rate, ok := rateFor(card.PSPRates, req.PaymentMethod); ok {
+			cost.PSPFee = rate.Apply(req.Amount)
+			cost.Components = append(cost.Components, CostComponent{
+				Type:   CostComponentPSP,
+				Amount: cost.PSPFee,
+				Basis:  req.PaymentMethod,
+			})
+		}
+	}
+
+	if usage.Screened {
+		if rate, ok := rateFor(card.ScreeningRates, usage.VerificationLevel); ok {
+			cost.ScreeningFee = rate.Apply(req.Amount)
+		}
+		cost.ScreeningFee = roundSummaryValue(cost.ScreeningFee+float64(usage.ExtraChecks)*card.ExtraCheckFee, 4)
+		if cost.ScreeningFee > 0 {
+			cost.Components = append(cost.Components, CostComponent{
+				Type:   CostComponentScreening,
+				Amount: cost.ScreeningFee,
+				Basis:  usage.VerificationLevel,
+			})
+		}
+	}
+
+	if usage.BureauConsulted {
+		cost.BureauFee = card.BureauRate.Apply(req.Amount)
+		if cost.BureauFee > 0 {
+			cost.Components = append(cost.Components, CostComponent{
+				Type:   CostComponentBureau,
+				Amount: cost.BureauFee,
+			})
+		}
+	}
+
+	cost.TotalCost = roundSummaryValue(cost.PSPFee+cost.ScreeningFee+cost.BureauFee, 4)
+	return cost
+}
+
+// RecordPaymentCost atribui e regista o custo de processamento de um pagamento concluído
+// Falhas no registo não afetam o pagamento
+func (s *CostAccountingService) RecordPaymentCost(ctx context.Context, req *PaymentRequest, resp *PaymentResponse, usage CostUsage) {
+	ctx, span := s.tracer.StartSpan(ctx, "CostAccountingService.RecordPaymentCost")
+	defer span.End()
+
+	// Pagamentos pendentes são registados quando o processamento terminar
+	if resp.Status == TransactionStatusPending {
+		return
+	}
+
+	cost := s.Estimate(req, resp.Status, usage)
+	if cost.RateCardID == "" {
+		s.metricsRecorder.CounterInc("payment_gateway_cost_rate_card_missing_total", map[string]string{
+			"region":   req.RegionCode,
+			"currency": req.Currency,
+		})
+	}
+
+	if err := s.store.SaveTransactionCost(ctx, cost); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao registar custo da transação",
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+		return
+	}
+
+	for _, component := range cost.Components {
+		s.metricsRecorder.HistogramObserve("payment_gateway_transaction_cost", component.Amount, map[string]string{
+			"region":    req.RegionCode,
+			"component": component.Type,
+		})
+	}
+}
+
+// GetTransactionCost retorna o custo atribuído a uma transação do tenant
+func (s *CostAccountingService) GetTransactionCost(ctx context.Context, tenantID, transactionID string) (*TransactionCost, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "CostAccountingService.GetTransactionCost")
+	defer span.End()
+
+	cost, err := s.store.GetTransactionCost(ctx, tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if cost == nil {
+		return nil, ErrTransactionCostNotFound
+	}
+	return cost, nil
+}
+
+// CostReport agrega os custos do tenant no período por mercado, comerciante e moeda
+func (s *CostAccountingService) CostReport(ctx context.Context, tenantID string, filter CostReportFilter) (*CostReport, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "CostAccountingService.CostReport")
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.store.SummarizeCosts(ctx, tenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		summary.finalize()
+	}
+
+	return &CostReport{
+		TenantID:    tenantID,
+		From:        filter.From,
+		To:          filter.To,
+		Summaries:   summaries,
+		GeneratedAt: s.now(),
+	}, nil
+}
+
+// rateCardFor retorna a tabela mais específica aplicável ao mercado e à moeda
+func (s *CostAccountingService) rateCardFor(regionCode, currency string) *CostRateCard {
+	for i := range s.rateCards {
+		if s.rateCards[i].matches(regionCode, currency) {
+			return &s.rateCards[i]
+		}
+	}
+	return nil
+}
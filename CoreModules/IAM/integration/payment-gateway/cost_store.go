@@ -0,0 +1,115 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CostStore define a persistência dos custos atribuídos às transações
+type CostStore interface {
+	// SaveTransactionCost grava o custo da transação, substituindo um registo anterior da mesma transação
+	SaveTransactionCost(ctx context.Context, cost *TransactionCost) error
+
+	// GetTransactionCost recupera o custo de uma transação do tenant, ou nil se não existir
+	GetTransactionCost(ctx context.Context, tenantID, transactionID string) (*TransactionCost, error)
+
+	// SummarizeCosts agrega os custos do tenant no intervalo do filtro por mercado, comerciante e moeda
+	SummarizeCosts(ctx context.Context, tenantID string, filter CostReportFilter) ([]*MerchantCostSummary, error)
+}
+
+// InMemoryCostStore armazena os custos das transações em memória
+type InMemoryCostStore struct {
+	costs map[string]*TransactionCost // Por tenant e transação
+	mutex sync.RWMutex
+}
+
+// NewInMemoryCostStore cria um novo armazenamento em memória
+func NewInMemoryCostStore() *InMemoryCostStore {
+	return &InMemoryCostStore{costs: make(map[string]*TransactionCost)}
+}
+
+// SaveTransactionCost grava uma cópia do custo
+func (s *InMemoryCostStore) SaveTransactionCost(ctx context.Context, cost *TransactionCost) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.costs[cost.TenantID+"\x00"+cost.TransactionID] = copyTransactionCost(cost)
+	return nil
+}
+
+// GetTransactionCost recupera uma cópia do custo da transação
+func (s *InMemoryCostStore) GetTransactionCost(ctx context.Context, tenantID, transactionID string) (*TransactionCost, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cost, exists := s.costs[tenantID+"\x00"+transactionID]
+	if !exists {
+		return nil, nil
+	}
+	return copyTransactionCost(cost), nil
+}
+
+// SummarizeCosts agrega os custos do tenant no intervalo do filtro
+func (s *InMemoryCostStore) SummarizeCosts(ctx context.Context, tenantID string, filter CostReportFilter) ([]*MerchantCostSummary, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	groups := make(map[string]*MerchantCostSummary)
+	for _, cost := range s.costs {
+		if cost.TenantID != tenantID ||
+			cost.OccurredAt.Before(filter.from) || !cost.OccurredAt.Before(filter.to) ||
+			(filter.RegionCode != "" && cost.RegionCode != filter.RegionCode) ||
+			(filter.MerchantID != "" && cost.MerchantID != filter.MerchantID) {
+			continue
+		}
+
+		key := strings.Join([]string{cost.RegionCode, cost.MerchantID, cost.Currency}, "\x00")
+		summary, exists := groups[key]
+		if !exists {
+			summary = &MerchantCostSummary{
+				TenantID:   cost.TenantID,
+				RegionCode: cost.RegionCode,
+				MerchantID: cost.MerchantID,
+				Currency:   cost.Currency,
+			}
+			groups[key] = summary
+		}
+
+		summary.TransactionCount++
+		if cost.Status == TransactionStatusApproved {
+			summary.ApprovedVolume += cost.Amount
+		}
+		summary.PSPFees += cost.PSPFee
+		summary.ScreeningFees += cost.ScreeningFee
+		summary.BureauFees += cost.BureauFee
+		summary.TotalCost += cost.TotalCost
+	}
+
+	summaries := make([]*MerchantCostSummary, 0, len(groups))
+	for _, summary := range groups {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.RegionCode != b.RegionCode {
+			return a.RegionCode < b.RegionCode
+		}
+		if a.MerchantID != b.MerchantID {
+			return a.MerchantID < b.MerchantID
+		}
+		return a.Currency < b.Currency
+	})
+	if filter.Limit > 0 && len(summaries) > filter.Limit {
+		summaries = summaries[:filter.Limit]
+	}
+	return summaries, nil
+}
+
+// copyTransactionCost copia o custo, incluindo os componentes
+func copyTransactionCost(cost *TransactionCost) *TransactionCost {
+	copied := *cost
+	copied.Components = append([]CostComponent{}, cost.Components...)
+	return &copied
+}
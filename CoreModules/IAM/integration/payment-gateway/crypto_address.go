@@ -0,0 +1,220 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// bech32Charset é o alfabeto dos endereços bech32 (BIP 173)
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Constantes do checksum: bech32 na versão 0 do witness (BIP 173) e bech32m nas seguintes (BIP 350)
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// base58Alphabet é o alfabeto Base58 do Bitcoin
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// ValidateCryptoAddress valida o formato e o checksum de um endereço na rede indicada: Bitcoin em
+// Base58Check (P2PKH, P2SH) ou bech32/bech32m (SegWit, Taproot) e Ethereum em hexadecimal com o
+// checksum EIP-55 quando tem maiúsculas e minúsculas. Os endereços das redes de teste só são aceites
+// com testnet
+func ValidateCryptoAddress(network, address string, testnet bool) error {
+	if address == "" {
+		return fmt.Errorf("%w: endereço vazio", ErrCryptoAddressInvalid)
+	}
+	switch network {
+	case CryptoNetworkBitcoin:
+		return validateBitcoinAddress(address, testnet)
+	case CryptoNetworkEthereum:
+		return validateEthereumAddress(address)
+	default:
+		return fmt.Errorf("%w: rede %s", ErrCryptoAssetUnsupported, network)
+	}
+}
+
+// validateBitcoinAddress valida endereços SegWit pelo checksum bech32 e os restantes pelo Base58Check
+func validateBitcoinAddress(address string, testnet bool) error {
+	hrps := map[string]bool{"bc": true}
+	versions := map[byte]bool{0x00: true, 0x05: true} // P2PKH e P2SH
+	if testnet {
+		hrps["tb"] = true
+		versions[0x6f] = true
+		versions[0xc4] = true
+	}
+
+	lower := strings.ToLower(address)
+	if hrp, _, found := strings.Cut(lower, "1"); found && (hrp == "bc" || hrp == "tb") {
+		if !hrps[hrp] {
+			return fmt.Errorf("%w: endereço da rede de teste", ErrCryptoAddressInvalid)
+		}
+		return validateSegwitAddress(address)
+	}
+
+	decoded, ok := decodeBase58(address)
+	if !ok || len(decoded) != 25 {
+		return fmt.Errorf("%w: Base58 inválido", ErrCryptoAddressInvalid)
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[21:]) {
+		return fmt.Errorf("%w: checksum Base58Check", ErrCryptoAddressInvalid)
+	}
+	if !versions[decoded[0]] {
+		return fmt.Errorf("%w: versão 0x%02x", ErrCryptoAddressInvalid, decoded[0])
+	}
+	return nil
+}
+
+// validateSegwitAddress valida o checksum, a versão do witness e o tamanho do programa
+func validateSegwitAddress(address string) error {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return fmt.Errorf("%w: bech32 com maiúsculas e minúsculas", ErrCryptoAddressInvalid)
+	}
+	address = strings.ToLower(address)
+	if len(address) > 90 {
+		return fmt.Errorf("%w: bech32 demasiado longo", ErrCryptoAddressInvalid)
+	}
+
+	separator := strings.LastIndexByte(address, '1')
+	hrp, data := address[:separator], address[separator+1:]
+	if len(data) < 7 {
+		return fmt.Errorf("%w: bech32 truncado", ErrCryptoAddressInvalid)
+	}
+	values := make([]byte, len(data))
+	for i := 0; i < len(data); i++ {
+		index := strings.IndexByte(bech32Charset, data[i])
+		if index < 0 {
+			return fmt.Errorf("%w: carácter bech32 inválido", ErrCryptoAddressInvalid)
+		}
+		values[i] = byte(index)
+	}
+
+	version := values[0]
+	expected := uint32(bech32Const)
+	if version > 0 {
+		expected = bech32mConst
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != expected {
+		return fmt.Errorf("%w: checksum bech32", ErrCryptoAddressInvalid)
+	}
+
+	program, ok := convertBech32Bits(values[1 : len(values)-6])
+	switch {
+	case !ok || version > 16 || len(program) < 2 || len(program) > 40:
+		return fmt.Errorf("%w: programa witness inválido", ErrCryptoAddressInvalid)
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return fmt.Errorf("%w: programa witness v0 com %d bytes", ErrCryptoAddressInvalid, len(program))
+	}
+	return nil
+}
+
+// bech32Polymod calcula o checksum BCH dos valores de 5 bits
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+// bech32HRPExpand expande a parte legível para o cálculo do checksum
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBech32Bits reagrupa os valores de 5 bits em bytes, rejeitando o preenchimento inválido
+func convertBech32Bits(values []byte) ([]byte, bool) {
+	var program []byte
+	accumulator, bits := 0, 0
+	for _, value := range values {
+		accumulator = (accumulator<<5 | int(value)) & 0xfff
+		bits += 5
+		for bits >= 8 {
+			bits -= 8
+			program = append(program, byte(accumulator>>bits))
+		}
+	}
+	if bits >= 5 || (accumulator<<(8-bits))&0xff != 0 {
+		return nil, false
+	}
+	return program, true
+}
+
+// decodeBase58 decodifica um valor Base58, preservando os zeros iniciais
+func decodeBase58(value string) ([]byte, bool) {
+	var decoded []byte
+	for i := 0; i < len(value); i++ {
+		carry := strings.IndexByte(base58Alphabet, value[i])
+		if carry < 0 {
+			return nil, false
+		}
+		for j := len(decoded) - 1; j >= 0; j-- {
+			carry += int(decoded[j]) * 58
+			decoded[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			decoded = append([]byte{byte(carry)}, decoded...)
+			carry >>= 8
+		}
+	}
+	for i := 0; i < len(value) && value[i] == '1'; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	return decoded, true
+}
+
+// validateEthereumAddress valida o formato hexadecimal e, com maiúsculas e minúsculas, o checksum EIP-55
+func validateEthereumAddress(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return fmt.Errorf("%w: esperado 0x seguido de 40 dígitos hexadecimais", ErrCryptoAddressInvalid)
+	}
+	digits := address[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return fmt.Errorf("%w: dígitos hexadecimais inválidos", ErrCryptoAddressInvalid)
+	}
+	if strings.ToLower(digits) == digits || strings.ToUpper(digits) == digits {
+		return nil
+	}
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(strings.ToLower(digits)))
+	sum := hash.Sum(nil)
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		if c < 'A' || (c > 'F' && c < 'a') || c > 'f' {
+			continue
+		}
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+		if (nibble >= 8) != (c <= 'F') {
+			return fmt.Errorf("%w: checksum EIP-55", ErrCryptoAddressInvalid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CryptoPaymentHandler expõe às equipas de suporte os criptoativos aceites e o estado dos pagamentos,
+// com as confirmações na rede e os dados da travel rule
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type CryptoPaymentHandler struct {
+	service *CryptoPaymentService
+}
+
+// NewCryptoPaymentHandler cria uma nova instância do CryptoPaymentHandler
+func NewCryptoPaymentHandler(service *CryptoPaymentService) *CryptoPaymentHandler {
+	return &CryptoPaymentHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *CryptoPaymentHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/crypto-payments/assets", h.ListAssets).Methods(http.MethodGet)
+	router.HandleFunc("/support/crypto-payments/{paymentId}", h.GetPayment).Methods(http.MethodGet)
+}
+
+// ListAssets lista os criptoativos aceites com a rede e as confirmações exigidas
+func (h *CryptoPaymentHandler) ListAssets(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.Assets())
+}
+
+// GetPayment retorna a cotação, os depósitos e a travel rule de um pagamento do tenant
+func (h *CryptoPaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	payment, err := h.service.Get(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["paymentId"])
+	if err != nil {
+		if errors.Is(err, ErrCryptoPaymentNotFound) {
+			respondWithError(w, http.StatusNotFound, "not_found", "Pagamento em criptoativos não encontrado")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao recuperar pagamento em criptoativos")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payment)
+}
@@ -0,0 +1,209 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Redes blockchain dos pagamentos em criptoativos
+const (
+	CryptoNetworkBitcoin  = "bitcoin"
+	CryptoNetworkEthereum = "ethereum"
+)
+
+// Estados de um pagamento em criptoativos
+const (
+	CryptoPaymentStatusAwaitingDeposit = "awaiting_deposit" // Cotação bloqueada, a aguardar o depósito
+	CryptoPaymentStatusConfirming      = "confirming"       // Valor recebido, abaixo das confirmações exigidas
+	CryptoPaymentStatusConfirmed       = "confirmed"
+	CryptoPaymentStatusExpired         = "expired"   // Sem depósito dentro da janela da cotação
+	CryptoPaymentStatusUnderpaid       = "underpaid" // Depósito abaixo do valor cotado no fim da janela
+)
+
+// Estados da troca de dados da travel rule (Recomendação 16 do GAFI)
+const (
+	TravelRuleStatusNotRequired = "not_required" // Valor abaixo do limiar do mercado
+	TravelRuleStatusExchanged   = "exchanged"    // Dados trocados com o VASP do ordenante
+	TravelRuleStatusSelfHosted  = "self_hosted"  // Carteira própria do ordenante, com prova de controlo
+)
+
+// Valores padrão dos pagamentos em criptoativos
+const (
+	DefaultCryptoQuoteWindow      = 15 * time.Minute
+	DefaultCryptoTrackingInterval = 30 * time.Second
+	DefaultCryptoProviderTimeout  = 10 * time.Second
+
+	cryptoMaxQuoteWindow = time.Hour
+	cryptoQuoteDecimals  = 8 // Casas decimais máximas do valor cotado
+)
+
+// Erros dos pagamentos em criptoativos
+var (
+	ErrCryptoPaymentNotFound     = errors.New("pagamento em criptoativos não encontrado")
+	ErrCryptoPaymentRejected     = errors.New("pagamento em criptoativos recusado antes da cotação")
+	ErrCryptoDetailsMissing      = errors.New("dados do pagamento em criptoativos ausentes")
+	ErrCryptoAssetUnsupported    = errors.New("criptoativo não suportado")
+	ErrCryptoAddressInvalid      = errors.New("endereço de carteira inválido")
+	ErrCryptoRateUnavailable     = errors.New("cotação do criptoativo indisponível")
+	ErrCryptoProviderUnavailable = errors.New("fornecedor da rede blockchain não configurado")
+	ErrCryptoQuoteExpired        = errors.New("janela da cotação terminada sem depósito")
+	ErrCryptoUnderpaid           = errors.New("depósito abaixo do valor cotado")
+	ErrTravelRuleDataMissing     = errors.New("dados do ordenante exigidos pela travel rule ausentes")
+	ErrTravelRuleUnavailable     = errors.New("troca de dados da travel rule não configurada")
+)
+
+// CryptoAsset define um criptoativo aceite e a rede em que é recebido
+type CryptoAsset struct {
+	Symbol                string `json:"symbol"`
+	Network               string `json:"network"`
+	Decimals              int    `json:"decimals"`               // Casas decimais da unidade mínima (satoshi, wei)
+	RequiredConfirmations int    `json:"required_confirmations"` // Blocos até o depósito ser considerado final
+	Contract              string `json:"contract,omitempty"`     // Contrato ERC-20; vazio no ativo nativo da rede
+}
+
+// DefaultCryptoAssets retorna os criptoativos aceites quando a configuração não define outros
+func DefaultCryptoAssets() map[string]CryptoAsset {
+	return map[string]CryptoAsset{
+		"BTC":  {Symbol: "BTC", Network: CryptoNetworkBitcoin, Decimals: 8, RequiredConfirmations: 3},
+		"ETH":  {Symbol: "ETH", Network: CryptoNetworkEthereum, Decimals: 18, RequiredConfirmations: 12},
+		"USDC": {Symbol: "USDC", Network: CryptoNetworkEthereum, Decimals: 6, RequiredConfirmations: 12, Contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+		"USDT": {Symbol: "USDT", Network: CryptoNetworkEthereum, Decimals: 6, RequiredConfirmations: 12, Contract: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+	}
+}
+
+// TravelRuleThreshold é o valor a partir do qual a travel rule se aplica num mercado
+type TravelRuleThreshold struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// DefaultTravelRuleThresholds retorna os limiares por mercado: 3000 USD nos EUA (Bank Secrecy Act),
+// qualquer valor na UE (Regulamento (UE) 2023/1113) e 1000 USD nos restantes (Recomendação 16 do GAFI)
+func DefaultTravelRuleThresholds() map[string]TravelRuleThreshold {
+	return map[string]TravelRuleThreshold{
+		RegionUSA:    {Amount: 3000, Currency: "USD"},
+		RegionEU:     {Amount: 0, Currency: "EUR"},
+		RegionGlobal: {Amount: 1000, Currency: "USD"},
+	}
+}
+
+// TravelRuleParty identifica o ordenante ou o beneficiário de uma transferência de criptoativos
+// Além do nome e da carteira, o ordenante é identificado pela morada, documento ou data de nascimento
+type TravelRuleParty struct {
+	Name          string `json:"name"`
+	WalletAddress string `json:"wallet_address"`
+	VASPID        string `json:"vasp_id,omitempty"` // LEI ou DID do VASP que custodia a carteira; vazio em carteira própria
+	Address       string `json:"address,omitempty"`
+	NationalID    string `json:"national_id,omitempty"`
+	DateOfBirth   string `json:"date_of_birth,omitempty"` // AAAA-MM-DD
+	Country       string `json:"country,omitempty"`       // ISO 3166-1 alfa-2
+}
+
+// CryptoPaymentDetails são os dados específicos de um pagamento em criptoativos
+type CryptoPaymentDetails struct {
+	Asset          string           `json:"asset"`                     // Símbolo do criptoativo (BTC, ETH, USDC, USDT)
+	PayerAddress   string           `json:"payer_address"`             // Carteira de origem do pagador
+	PayerVASPID    string           `json:"payer_vasp_id,omitempty"`   // VASP que custodia a carteira; vazio em carteira própria
+	Originator     *TravelRuleParty `json:"originator,omitempty"`      // Exigido a partir do limiar da travel rule
+	OwnershipProof string           `json:"ownership_proof,omitempty"` // Mensagem assinada pela carteira própria do pagador
+}
+
+// TravelRuleMessage são os dados do ordenante e do beneficiário trocados entre VASPs
+type TravelRuleMessage struct {
+	TransferID   string          `json:"transfer_id"`
+	Asset        string          `json:"asset"`
+	Network      string          `json:"network"`
+	Amount       float64         `json:"amount"`
+	FiatAmount   float64         `json:"fiat_amount"`
+	FiatCurrency string          `json:"fiat_currency"`
+	Originator   TravelRuleParty `json:"originator"`
+	Beneficiary  TravelRuleParty `json:"beneficiary"`
+}
+
+// TravelRuleRecord regista a avaliação e a troca de dados da travel rule de um pagamento
+type TravelRuleRecord struct {
+	Status         string              `json:"status"`
+	Threshold      TravelRuleThreshold `json:"threshold"`
+	Value          float64             `json:"value"` // Valor do pagamento na moeda do limiar
+	Originator     *TravelRuleParty    `json:"originator,omitempty"`
+	Beneficiary    *TravelRuleParty    `json:"beneficiary,omitempty"`
+	OwnershipProof string              `json:"ownership_proof,omitempty"`
+	Reference      string              `json:"reference,omitempty"` // Referência da troca no protocolo
+	ExchangedAt    *time.Time          `json:"exchanged_at,omitempty"`
+}
+
+// BlockchainTransfer é uma transferência recebida num endereço de depósito
+type BlockchainTransfer struct {
+	TxHash        string    `json:"tx_hash"`
+	FromAddress   string    `json:"from_address,omitempty"` // Vazio quando a rede não tem remetente único (UTXO)
+	Amount        float64   `json:"amount"`
+	Confirmations int       `json:"confirmations"`
+	SeenAt        time.Time `json:"seen_at"` // Primeira observação, na mempool ou em bloco
+}
+
+// CryptoPayment acompanha um pagamento em criptoativos, da cotação às confirmações na rede
+type CryptoPayment struct {
+	ID                    string           `json:"id" db:"id"`
+	TenantID              string           `json:"tenant_id" db:"tenant_id"`
+	TransactionID         string           `json:"transaction_id" db:"transaction_id"`
+	MerchantID            string           `json:"merchant_id" db:"merchant_id"`
+	UserID                string           `json:"-" db:"user_id"`
+	RegionCode            string           `json:"region_code" db:"region_code"`
+	Asset                 string           `json:"asset" db:"asset"`
+	Network               string           `json:"network" db:"network"`
+	FiatAmount            float64          `json:"fiat_amount" db:"fiat_amount"`
+	FiatCurrency          string           `json:"fiat_currency" db:"fiat_currency"`
+	Rate                  float64          `json:"rate" db:"rate"` // Preço do ativo bloqueado na cotação
+	CryptoAmount          float64          `json:"crypto_amount" db:"crypto_amount"`
+	ReceivedAmount        float64          `json:"received_amount" db:"received_amount"`
+	PayerAddress          string           `json:"payer_address" db:"payer_address"`
+	DepositAddress        string           `json:"deposit_address" db:"deposit_address"`
+	RequiredConfirmations int              `json:"required_confirmations" db:"required_confirmations"`
+	Confirmations         int              `json:"confirmations" db:"confirmations"`
+	TxHashes              []string         `json:"tx_hashes,omitempty" db:"-"`
+	Status                string           `json:"status" db:"status"`
+	TravelRule            TravelRuleRecord `json:"travel_rule" db:"-"`
+	QuotedAt              time.Time        `json:"quoted_at" db:"quoted_at"`
+	ExpiresAt             time.Time        `json:"expires_at" db:"expires_at"` // Fim da janela da cotação
+	ConfirmedAt           *time.Time       `json:"confirmed_at,omitempty" db:"confirmed_at"`
+}
+
+// CryptoProviderConfig define o endpoint do nó ou do fornecedor de uma rede
+type CryptoProviderConfig struct {
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"-"`
+}
+
+// CryptoPaymentConfig contém as configurações dos pagamentos em criptoativos
+type CryptoPaymentConfig struct {
+	// Criptoativos aceites por símbolo (padrão: DefaultCryptoAssets)
+	Assets map[string]CryptoAsset `json:"assets"`
+
+	// Nó ou fornecedor de infraestrutura por rede blockchain
+	Providers map[string]CryptoProviderConfig `json:"providers"`
+
+	// Preço de referência dos criptoativos por par "BTC/USD"
+	Rates map[string]float64 `json:"rates"`
+
+	// Limiar da travel rule por mercado (padrão: DefaultTravelRuleThresholds)
+	TravelRuleThresholds map[string]TravelRuleThreshold `json:"travel_rule_thresholds"`
+
+	// Serviço de troca de dados entre VASPs; vazio recusa carteiras em VASP acima do limiar
+	TravelRuleEndpoint string `json:"travel_rule_endpoint"`
+	TravelRuleAPIKey   string `json:"-"`
+
+	// LEI do gateway como VASP beneficiário
+	VASPID string `json:"vasp_id"`
+
+	// Aceita endereços das redes de teste; nunca ativo em produção
+	Testnet bool `json:"testnet"`
+
+	QuoteWindow      time.Duration `json:"quote_window"` // Janela de bloqueio da cotação (máximo 1h)
+	TrackingInterval time.Duration `json:"tracking_interval"`
+	ProviderTimeout  time.Duration `json:"provider_timeout"`
+
+	// Resilience sobrepõe ProviderTimeout e define as novas tentativas nos fornecedores e na travel rule
+	Resilience resilience.Policy `json:"resilience"`
+}
@@ -0,0 +1,133 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresCryptoPaymentStore implementa CryptoPaymentStore para PostgreSQL
+type PostgresCryptoPaymentStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresCryptoPaymentStore cria uma nova instância de PostgresCryptoPaymentStore
+func NewPostgresCryptoPaymentStore(db *sqlx.DB) *PostgresCryptoPaymentStore {
+	return &PostgresCryptoPaymentStore{db: db}
+}
+
+// dbCryptoPayment é a representação de CryptoPayment na base de dados
+type dbCryptoPayment struct {
+	CryptoPayment
+	TxHashesJSON   []byte `db:"tx_hashes"`
+	TravelRuleJSON []byte `db:"travel_rule"`
+}
+
+// cryptoPaymentColumns são as colunas lidas nas consultas de pagamentos em criptoativos
+const cryptoPaymentColumns = `id, tenant_id, transaction_id, merchant_id, user_id, region_code, asset, network,
+	fiat_amount, fiat_currency, rate, crypto_amount, received_amount, payer_address, deposit_address,
+	required_confirmations, confirmations, tx_hashes, status, travel_rule, quoted_at, expires_at, confirmed_at`
+
+// SaveCryptoPayment grava o pagamento, substituindo o estado anterior
+func (r *PostgresCryptoPaymentStore) SaveCryptoPayment(ctx context.Context, payment *CryptoPayment) error {
+	row := &dbCryptoPayment{CryptoPayment: *payment}
+
+	var err error
+	if row.TxHashesJSON, err = json.Marshal(payment.TxHashes); err != nil {
+		return fmt.Errorf("falha ao codificar transferências do pagamento: %w", err)
+	}
+	if row.TravelRuleJSON, err = json.Marshal(payment.TravelRule); err != nil {
+		return fmt.Errorf("falha ao codificar travel rule do pagamento: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.crypto_payments (
+			id, tenant_id, transaction_id, merchant_id, user_id, region_code, asset, network,
+			fiat_amount, fiat_currency, rate, crypto_amount, received_amount, payer_address, deposit_address,
+			required_confirmations, confirmations, tx_hashes, status, travel_rule, quoted_at, expires_at, confirmed_at
+		) VALUES (
+			:id, :tenant_id, :transaction_id, :merchant_id, :user_id, :region_code, :asset, :network,
+			:fiat_amount, :fiat_currency, :rate, :crypto_amount, :received_amount, :payer_address, :deposit_address,
+			:required_confirmations, :confirmations, :tx_hashes, :status, :travel_rule, :quoted_at, :expires_at, :confirmed_at
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			received_amount = EXCLUDED.received_amount,
+			confirmations = EXCLUDED.confirmations,
+			tx_hashes = EXCLUDED.tx_hashes,
+			status = EXCLUDED.status,
+			confirmed_at = EXCLUDED.confirmed_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar pagamento em criptoativos: %w", err)
+	}
+
+	return nil
+}
+
+// GetCryptoPayment recupera o pagamento do tenant
+func (r *PostgresCryptoPaymentStore) GetCryptoPayment(ctx context.Context, tenantID, id string) (*CryptoPayment, error) {
+	query := `SELECT ` + cryptoPaymentColumns + ` FROM payment_gateway.crypto_payments WHERE tenant_id = $1 AND id = $2`
+	return r.get(ctx, query, tenantID, id)
+}
+
+// GetCryptoPaymentByTransaction recupera o último pagamento da transação do tenant
+func (r *PostgresCryptoPaymentStore) GetCryptoPaymentByTransaction(ctx context.Context, tenantID, transactionID string) (*CryptoPayment, error) {
+	query := `SELECT ` + cryptoPaymentColumns + ` FROM payment_gateway.crypto_payments
+		WHERE tenant_id = $1 AND transaction_id = $2
+		ORDER BY quoted_at DESC LIMIT 1`
+	return r.get(ctx, query, tenantID, transactionID)
+}
+
+// ListPendingCryptoPayments lista os pagamentos a aguardar o depósito ou as confirmações
+func (r *PostgresCryptoPaymentStore) ListPendingCryptoPayments(ctx context.Context) ([]*CryptoPayment, error) {
+	var rows []dbCryptoPayment
+	query := `SELECT ` + cryptoPaymentColumns + ` FROM payment_gateway.crypto_payments
+		WHERE status IN ('awaiting_deposit', 'confirming')
+		ORDER BY quoted_at`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("falha ao listar pagamentos em criptoativos pendentes: %w", err)
+	}
+
+	payments := make([]*CryptoPayment, 0, len(rows))
+	for i := range rows {
+		payment, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
+
+// get recupera um pagamento pela consulta indicada
+func (r *PostgresCryptoPaymentStore) get(ctx context.Context, query string, args ...interface{}) (*CryptoPayment, error) {
+	var row dbCryptoPayment
+	if err := r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCryptoPaymentNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar pagamento em criptoativos: %w", err)
+	}
+	return row.decode()
+}
+
+// decode descodifica as transferências e os dados da travel rule
+func (row *dbCryptoPayment) decode() (*CryptoPayment, error) {
+	payment := row.CryptoPayment
+	if len(row.TxHashesJSON) > 0 {
+		if err := json.Unmarshal(row.TxHashesJSON, &payment.TxHashes); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar transferências do pagamento: %w", err)
+		}
+	}
+	if len(row.TravelRuleJSON) > 0 {
+		if err := json.Unmarshal(row.TravelRuleJSON, &payment.TravelRule); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar travel rule do pagamento: %w", err)
+		}
+	}
+	return &payment, nil
+}
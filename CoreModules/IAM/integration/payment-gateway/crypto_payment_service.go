@@ -0,0 +1,542 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// CryptoPaymentService bloqueia a cotação dos pagamentos em criptoativos, aplica a travel rule e
+// acompanha os depósitos até às confirmações exigidas pela rede
+// O pagamento fica pendente até às confirmações; o desfecho é notificado ao comerciante por webhook
+type CryptoPaymentService struct {
+	config     CryptoPaymentConfig
+	store      CryptoPaymentStore
+	providers  map[string]BlockchainProvider // Por rede
+	rates      CryptoRateSource
+	travelRule TravelRuleExchanger
+	webhooks   *WebhookDeliveryService
+
+	// Serializa o acompanhamento dos depósitos
+	mutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewCryptoPaymentService cria o serviço com os fornecedores de cada rede e, quando
+// TravelRuleEndpoint é configurado, a troca de dados da travel rule
+func NewCryptoPaymentService(config CryptoPaymentConfig, store CryptoPaymentStore) (*CryptoPaymentService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-crypto-payments",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if len(config.Assets) == 0 {
+		config.Assets = DefaultCryptoAssets()
+	}
+	if len(config.TravelRuleThresholds) == 0 {
+		config.TravelRuleThresholds = DefaultTravelRuleThresholds()
+	}
+	if config.QuoteWindow <= 0 {
+		config.QuoteWindow = DefaultCryptoQuoteWindow
+	}
+	if config.QuoteWindow > cryptoMaxQuoteWindow {
+		config.QuoteWindow = cryptoMaxQuoteWindow
+	}
+	if config.TrackingInterval <= 0 {
+		config.TrackingInterval = DefaultCryptoTrackingInterval
+	}
+
+	providers := make(map[string]BlockchainProvider, len(config.Providers))
+	for network, provider := range config.Providers {
+		providers[network] = NewHTTPBlockchainProvider(network, provider, config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &CryptoPaymentService{
+		config:          config,
+		store:           store,
+		providers:       providers,
+		rates:           StaticCryptoRates(config.Rates),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	if config.TravelRuleEndpoint != "" {
+		service.travelRule = NewHTTPTravelRuleExchanger(config)
+	}
+
+	service.logger.Info("Serviço de pagamentos em criptoativos inicializado",
+		"total_assets", len(config.Assets),
+		"total_providers", len(providers),
+		"travel_rule", config.TravelRuleEndpoint != "")
+	return service, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes dos pagamentos confirmados, expirados
+// e pagos abaixo do valor cotado
+func (s *CryptoPaymentService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.webhooks = webhooks
+}
+
+// Start inicia o acompanhamento periódico dos depósitos
+func (s *CryptoPaymentService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.TrackingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Track(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Acompanhamento de pagamentos em criptoativos iniciado", "interval", s.config.TrackingInterval.String())
+}
+
+// Stop interrompe o acompanhamento periódico
+func (s *CryptoPaymentService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Assets retorna os criptoativos aceites ordenados por símbolo
+func (s *CryptoPaymentService) Assets() []CryptoAsset {
+	assets := make([]CryptoAsset, 0, len(s.config.Assets))
+	for _, asset := range s.config.Assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+	return assets
+}
+
+// Get retorna o pagamento do tenant
+func (s *CryptoPaymentService) Get(ctx context.Context, tenantID, id string) (*CryptoPayment, error) {
+	return s.store.GetCryptoPayment(ctx, tenantID, id)
+}
+
+// Create valida a carteira do pagador, bloqueia a cotação durante a janela, obtém o endereço de
+// depósito e aplica a travel rule a partir do limiar do mercado. As recusas antes da cotação envolvem
+// ErrCryptoPaymentRejected; uma transação com pagamento em curso ou confirmado retorna esse pagamento
+func (s *CryptoPaymentService) Create(ctx context.Context, req *PaymentRequest) (*CryptoPayment, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "CryptoPaymentService.Create")
+	defer span.End()
+
+	existing, err := s.store.GetCryptoPaymentByTransaction(ctx, req.TenantID, req.TransactionID)
+	if err != nil && !errors.Is(err, ErrCryptoPaymentNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+	if existing != nil && existing.Status != CryptoPaymentStatusExpired && existing.Status != CryptoPaymentStatusUnderpaid {
+		return existing, nil
+	}
+
+	asset, err := s.validate(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, s.reject(ctx, req, err)
+	}
+
+	rate, err := s.rates.Rate(ctx, asset.Symbol, req.Currency)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	now := s.now()
+	payment := &CryptoPayment{
+		ID:                    fmt.Sprintf("cpay-%s", uuid.New().String()),
+		TenantID:              req.TenantID,
+		TransactionID:         req.TransactionID,
+		MerchantID:            req.MerchantID,
+		UserID:                req.UserID,
+		RegionCode:            req.RegionCode,
+		Asset:                 asset.Symbol,
+		Network:               asset.Network,
+		FiatAmount:            roundAmount(req.Amount),
+		FiatCurrency:          req.Currency,
+		Rate:                  rate,
+		CryptoAmount:          cryptoQuoteAmount(req.Amount/rate, asset),
+		PayerAddress:          req.Crypto.PayerAddress,
+		RequiredConfirmations: asset.RequiredConfirmations,
+		Status:                CryptoPaymentStatusAwaitingDeposit,
+		QuotedAt:              now,
+		ExpiresAt:             now.Add(s.config.QuoteWindow),
+	}
+
+	payment.TravelRule, err = s.evaluateTravelRule(ctx, req, asset, payment)
+	if errors.Is(err, ErrTravelRuleDataMissing) || errors.Is(err, ErrTravelRuleUnavailable) {
+		span.RecordError(err)
+		return nil, s.reject(ctx, req, err)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	provider, exists := s.providers[asset.Network]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCryptoProviderUnavailable, asset.Network)
+	}
+	payment.DepositAddress, err = provider.DepositAddress(ctx, asset, payment.ID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := ValidateCryptoAddress(asset.Network, payment.DepositAddress, s.config.Testnet); err != nil {
+		return nil, fmt.Errorf("endereço de depósito do fornecedor: %w", err)
+	}
+
+	// Com a carteira custodiada por um VASP, os dados do ordenante e do beneficiário são trocados
+	// antes do depósito, para que o VASP do ordenante possa recusar a transferência
+	if payment.TravelRule.Beneficiary != nil {
+		payment.TravelRule.Beneficiary.WalletAddress = payment.DepositAddress
+	}
+	if payment.TravelRule.Status == TravelRuleStatusExchanged {
+		reference, err := s.travelRule.Exchange(ctx, TravelRuleMessage{
+			TransferID:   payment.ID,
+			Asset:        asset.Symbol,
+			Network:      asset.Network,
+			Amount:       payment.CryptoAmount,
+			FiatAmount:   payment.FiatAmount,
+			FiatCurrency: payment.FiatCurrency,
+			Originator:   *payment.TravelRule.Originator,
+			Beneficiary:  *payment.TravelRule.Beneficiary,
+		})
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("travel rule: %w", err)
+		}
+		exchangedAt := s.now()
+		payment.TravelRule.Reference = reference
+		payment.TravelRule.ExchangedAt = &exchangedAt
+	}
+
+	if err := s.store.SaveCryptoPayment(ctx, payment); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_crypto_payments_total", map[string]string{
+		"asset":  payment.Asset,
+		"status": payment.Status,
+	})
+
+	// Evento de auditoria da cotação bloqueada e da travel rule aplicada
+	s.logger.InfoWithContext(ctx, "Cotação de pagamento em criptoativos bloqueada",
+		"tenant_id", payment.TenantID,
+		"transaction_id", payment.TransactionID,
+		"crypto_payment_id", payment.ID,
+		"asset", payment.Asset,
+		"crypto_amount", payment.CryptoAmount,
+		"rate", payment.Rate,
+		"fiat_currency", payment.FiatCurrency,
+		"deposit_address", payment.DepositAddress,
+		"expires_at", payment.ExpiresAt.UTC().Format(time.RFC3339),
+		"travel_rule_status", payment.TravelRule.Status,
+		"travel_rule_reference", payment.TravelRule.Reference)
+	return payment, nil
+}
+
+// validate verifica o criptoativo, a carteira do pagador e o valor do pagamento
+func (s *CryptoPaymentService) validate(req *PaymentRequest) (CryptoAsset, error) {
+	if req.Crypto == nil {
+		return CryptoAsset{}, ErrCryptoDetailsMissing
+	}
+	asset, exists := s.config.Assets[strings.ToUpper(req.Crypto.Asset)]
+	if !exists {
+		return CryptoAsset{}, fmt.Errorf("%w: %s", ErrCryptoAssetUnsupported, req.Crypto.Asset)
+	}
+	if err := ValidateCryptoAddress(asset.Network, req.Crypto.PayerAddress, s.config.Testnet); err != nil {
+		return CryptoAsset{}, fmt.Errorf("carteira do pagador: %w", err)
+	}
+	if req.Amount <= 0 {
+		return CryptoAsset{}, fmt.Errorf("%w: valor deve ser positivo", ErrCryptoDetailsMissing)
+	}
+	return asset, nil
+}
+
+// reject regista a recusa do pagamento antes da cotação
+func (s *CryptoPaymentService) reject(ctx context.Context, req *PaymentRequest, err error) error {
+	asset := "unknown"
+	if req.Crypto != nil && req.Crypto.Asset != "" {
+		asset = strings.ToUpper(req.Crypto.Asset)
+	}
+
+	s.metricsRecorder.CounterInc("payment_crypto_payments_total", map[string]string{
+		"asset":  asset,
+		"status": "rejected",
+	})
+	// Evento de segurança: carteira inválida ou dados da travel rule em falta antes de qualquer depósito
+	s.logger.WarnWithContext(ctx, "Pagamento em criptoativos recusado antes da cotação",
+		"tenant_id", req.TenantID,
+		"transaction_id", req.TransactionID,
+		"user_id", req.UserID,
+		"asset", asset,
+		"error", err.Error())
+	return fmt.Errorf("%w: %w", ErrCryptoPaymentRejected, err)
+}
+
+// evaluateTravelRule compara o valor do pagamento com o limiar do mercado e valida os dados do
+// ordenante. A carteira própria do pagador exige prova de controlo; a carteira num VASP exige a troca
+// de dados, feita em Create depois de atribuído o endereço de depósito
+func (s *CryptoPaymentService) evaluateTravelRule(ctx context.Context, req *PaymentRequest, asset CryptoAsset, payment *CryptoPayment) (TravelRuleRecord, error) {
+	threshold, exists := s.config.TravelRuleThresholds[req.RegionCode]
+	if !exists {
+		threshold = s.config.TravelRuleThresholds[RegionGlobal]
+	}
+
+	record := TravelRuleRecord{Status: TravelRuleStatusNotRequired, Threshold: threshold, Value: payment.FiatAmount}
+	if threshold.Currency != "" && threshold.Currency != req.Currency {
+		rate, err := s.rates.Rate(ctx, asset.Symbol, threshold.Currency)
+		if err != nil {
+			return record, fmt.Errorf("travel rule: %w", err)
+		}
+		record.Value = roundAmount(payment.CryptoAmount * rate)
+	}
+	if record.Value < threshold.Amount {
+		return record, nil
+	}
+
+	details := req.Crypto
+	originator := details.Originator
+	if originator == nil || strings.TrimSpace(originator.Name) == "" ||
+		(originator.Address == "" && originator.NationalID == "" && originator.DateOfBirth == "") {
+		return record, fmt.Errorf("%w: %.2f %s a partir do limiar de %.2f %s", ErrTravelRuleDataMissing,
+			record.Value, threshold.Currency, threshold.Amount, threshold.Currency)
+	}
+
+	copied := *originator
+	copied.WalletAddress = details.PayerAddress
+	copied.VASPID = details.PayerVASPID
+	record.Originator = &copied
+	record.Beneficiary = &TravelRuleParty{Name: req.MerchantID, VASPID: s.config.VASPID}
+
+	if details.PayerVASPID == "" {
+		if strings.TrimSpace(details.OwnershipProof) == "" {
+			return record, fmt.Errorf("%w: prova de controlo da carteira própria", ErrTravelRuleDataMissing)
+		}
+		record.Status = TravelRuleStatusSelfHosted
+		record.OwnershipProof = details.OwnershipProof
+		return record, nil
+	}
+	if s.travelRule == nil {
+		return record, ErrTravelRuleUnavailable
+	}
+	record.Status = TravelRuleStatusExchanged
+	return record, nil
+}
+
+// Track consulta as transferências dos pagamentos em curso e retorna os que mudaram de estado
+// Só contam os depósitos observados dentro da janela da cotação; com a travel rule aplicada, os
+// depósitos de outra carteira que não a declarada não são creditados
+func (s *CryptoPaymentService) Track(ctx context.Context) []*CryptoPayment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending, err := s.store.ListPendingCryptoPayments(ctx)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao listar pagamentos em criptoativos pendentes", "error", err.Error())
+		return nil
+	}
+
+	var changed []*CryptoPayment
+	for _, payment := range pending {
+		asset := s.config.Assets[payment.Asset]
+		provider, exists := s.providers[payment.Network]
+		if !exists {
+			continue
+		}
+		transfers, err := provider.Transfers(ctx, asset, payment.DepositAddress)
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "Falha ao consultar depósitos do pagamento em criptoativos",
+				"crypto_payment_id", payment.ID,
+				"network", payment.Network,
+				"error", err.Error())
+			continue
+		}
+
+		if !s.apply(ctx, payment, transfers) {
+			continue
+		}
+		if err := s.store.SaveCryptoPayment(ctx, payment); err != nil {
+			s.logger.ErrorWithContext(ctx, "Falha ao atualizar pagamento em criptoativos",
+				"crypto_payment_id", payment.ID,
+				"error", err.Error())
+			continue
+		}
+		s.complete(ctx, payment)
+		changed = append(changed, payment)
+	}
+	return changed
+}
+
+// apply atualiza o pagamento com as transferências observadas e indica se o estado mudou
+func (s *CryptoPaymentService) apply(ctx context.Context, payment *CryptoPayment, transfers []BlockchainTransfer) bool {
+	now := s.now()
+	var received float64
+	var hashes []string
+	confirmations := -1
+	for _, transfer := range transfers {
+		seenAt := transfer.SeenAt
+		if seenAt.IsZero() {
+			seenAt = now
+		}
+		if !seenAt.Before(payment.ExpiresAt) {
+			continue
+		}
+		if payment.TravelRule.Originator != nil && transfer.FromAddress != "" &&
+			!strings.EqualFold(transfer.FromAddress, payment.PayerAddress) {
+			// Evento de segurança: depósito de uma carteira sem os dados exigidos pela travel rule
+			s.logger.WarnWithContext(ctx, "Depósito de carteira diferente da declarada na travel rule",
+				"tenant_id", payment.TenantID,
+				"crypto_payment_id", payment.ID,
+				"tx_hash", transfer.TxHash)
+			continue
+		}
+		received += transfer.Amount
+		hashes = append(hashes, transfer.TxHash)
+		if confirmations < 0 || transfer.Confirmations < confirmations {
+			confirmations = transfer.Confirmations
+		}
+	}
+	if confirmations < 0 {
+		confirmations = 0
+	}
+
+	previous := payment.Status
+	payment.ReceivedAmount = float64(cryptoUnits(received)) / math.Pow10(cryptoQuoteDecimals)
+	payment.Confirmations = confirmations
+	payment.TxHashes = hashes
+
+	switch {
+	case cryptoUnits(payment.ReceivedAmount) >= cryptoUnits(payment.CryptoAmount):
+		payment.Status = CryptoPaymentStatusConfirming
+		if confirmations >= payment.RequiredConfirmations {
+			payment.Status = CryptoPaymentStatusConfirmed
+			payment.ConfirmedAt = &now
+		}
+	case !now.Before(payment.ExpiresAt) && len(hashes) == 0:
+		payment.Status = CryptoPaymentStatusExpired
+	case !now.Before(payment.ExpiresAt):
+		payment.Status = CryptoPaymentStatusUnderpaid
+	}
+	return payment.Status != previous
+}
+
+// complete regista a mudança de estado e notifica o comerciante dos pagamentos concluídos, expirados
+// ou pagos abaixo do valor cotado
+func (s *CryptoPaymentService) complete(ctx context.Context, payment *CryptoPayment) {
+	s.metricsRecorder.CounterInc("payment_crypto_payments_total", map[string]string{
+		"asset":  payment.Asset,
+		"status": payment.Status,
+	})
+
+	switch payment.Status {
+	case CryptoPaymentStatusConfirming:
+		s.logger.InfoWithContext(ctx, "Depósito do pagamento em criptoativos a aguardar confirmações",
+			"crypto_payment_id", payment.ID,
+			"confirmations", payment.Confirmations,
+			"required", payment.RequiredConfirmations)
+	case CryptoPaymentStatusConfirmed:
+		// Evento de auditoria do pagamento concluído
+		s.logger.InfoWithContext(ctx, "Pagamento em criptoativos confirmado",
+			"tenant_id", payment.TenantID,
+			"crypto_payment_id", payment.ID,
+			"transaction_id", payment.TransactionID,
+			"received_amount", payment.ReceivedAmount,
+			"asset", payment.Asset,
+			"confirmations", payment.Confirmations,
+			"tx_hashes", strings.Join(payment.TxHashes, ","))
+		s.notify(ctx, payment, WebhookEventTransactionCompleted, "")
+	case CryptoPaymentStatusExpired:
+		s.notify(ctx, payment, WebhookEventTransactionFailed, ErrCryptoQuoteExpired.Error())
+	case CryptoPaymentStatusUnderpaid:
+		// Evento de auditoria: o valor recebido é devolvido manualmente ao pagador
+		s.logger.InfoWithContext(ctx, "Pagamento em criptoativos abaixo do valor cotado",
+			"tenant_id", payment.TenantID,
+			"crypto_payment_id", payment.ID,
+			"transaction_id", payment.TransactionID,
+			"received_amount", payment.ReceivedAmount,
+			"crypto_amount", payment.CryptoAmount,
+			"asset", payment.Asset)
+		s.notify(ctx, payment, WebhookEventTransactionFailed, ErrCryptoUnderpaid.Error())
+	}
+}
+
+// notify agenda a notificação ao comerciante do desfecho do pagamento em criptoativos
+func (s *CryptoPaymentService) notify(ctx context.Context, payment *CryptoPayment, eventType, reason string) {
+	if s.webhooks == nil {
+		return
+	}
+
+	status := TransactionStatusApproved
+	if eventType == WebhookEventTransactionFailed {
+		status = TransactionStatusDenied
+	}
+	if _, err := s.webhooks.Enqueue(ctx, WebhookEvent{
+		EventType:     eventType,
+		TenantID:      payment.TenantID,
+		MerchantID:    payment.MerchantID,
+		TransactionID: payment.TransactionID,
+		Status:        status,
+		PaymentMethod: PaymentMethodCrypto,
+		Amount:        payment.FiatAmount,
+		Currency:      payment.FiatCurrency,
+		ProcessorRef:  strings.Join(payment.TxHashes, ","),
+		Reason:        reason,
+		Market:        payment.RegionCode,
+	}); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao agendar notificação do pagamento em criptoativos",
+			"crypto_payment_id", payment.ID,
+			"transaction_id", payment.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// cryptoQuoteAmount arredonda para cima o valor cotado na precisão do ativo, para que o
+// comerciante não receba menos do que o valor da transação
+func cryptoQuoteAmount(amount float64, asset CryptoAsset) float64 {
+	decimals := asset.Decimals
+	if decimals <= 0 || decimals > cryptoQuoteDecimals {
+		decimals = cryptoQuoteDecimals
+	}
+	scale := math.Pow10(decimals)
+	return math.Ceil(math.Round(amount*scale*1e4)/1e4) / scale
+}
+
+// cryptoUnits converte um valor em unidades da precisão máxima de cotação, para comparação exata
+func cryptoUnits(amount float64) int64 {
+	return int64(math.Round(amount * math.Pow10(cryptoQuoteDecimals)))
+}
@@ -0,0 +1,323 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlockchainProvider atribui endereços de depósito pela ordem indicada e devolve as transferências registadas
+type fakeBlockchainProvider struct {
+	mutex     sync.Mutex
+	addresses []string
+	assigned  int
+	transfers map[string][]BlockchainTransfer
+}
+
+func (p *fakeBlockchainProvider) DepositAddress(ctx context.Context, asset CryptoAsset, reference string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	address := p.addresses[p.assigned%len(p.addresses)]
+	p.assigned++
+	return address, nil
+}
+
+func (p *fakeBlockchainProvider) Transfers(ctx context.Context, asset CryptoAsset, address string) ([]BlockchainTransfer, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]BlockchainTransfer(nil), p.transfers[address]...), nil
+}
+
+func (p *fakeBlockchainProvider) setTransfers(address string, transfers ...BlockchainTransfer) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.transfers[address] = transfers
+}
+
+type fakeTravelRuleExchanger struct {
+	messages []TravelRuleMessage
+}
+
+func (e *fakeTravelRuleExchanger) Exchange(ctx context.Context, message TravelRuleMessage) (string, error) {
+	e.messages = append(e.messages, message)
+	return fmt.Sprintf("TRP-%d", len(e.messages)), nil
+}
+
+// Endereços válidos usados como carteiras dos pagadores e endereços de depósito
+const (
+	testBTCPayerAddress   = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	testBTCDepositAddress = "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"
+	testETHPayerAddress   = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	testETHDepositAddress = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+)
+
+// newTestCryptoPaymentService cria o serviço com cotações fixas, relógio controlado e fornecedores de rede simulados
+func newTestCryptoPaymentService(t *testing.T, depositAddresses ...string) (*CryptoPaymentService, *fakeBlockchainProvider, *time.Time) {
+	t.Helper()
+
+	service, err := NewCryptoPaymentService(CryptoPaymentConfig{
+		Rates:  map[string]float64{"BTC/USD": 50000, "ETH/USD": 2500, "ETH/EUR": 2300},
+		VASPID: "LEI-INNOVABIZ-TEST",
+	}, NewInMemoryCryptoPaymentStore())
+	require.NoError(t, err)
+
+	provider := &fakeBlockchainProvider{addresses: depositAddresses, transfers: make(map[string][]BlockchainTransfer)}
+	service.providers = map[string]BlockchainProvider{
+		CryptoNetworkBitcoin:  provider,
+		CryptoNetworkEthereum: provider,
+	}
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, provider, &clock
+}
+
+// testCryptoRequest cria um pagamento em criptoativos na região e moeda indicadas
+func testCryptoRequest(id, region, currency string, amount float64, details CryptoPaymentDetails) *PaymentRequest {
+	req := testRiskRequest(region, currency, amount)
+	req.RequestID = "req-" + id
+	req.TransactionID = id
+	req.PaymentMethod = PaymentMethodCrypto
+	req.Crypto = &details
+	return req
+}
+
+func TestValidateCryptoAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		address string
+		testnet bool
+		valid   bool
+	}{
+		{"P2WPKH (bech32)", CryptoNetworkBitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", false, true},
+		{"bech32 em maiúsculas", CryptoNetworkBitcoin, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", false, true},
+		{"Taproot (bech32m)", CryptoNetworkBitcoin, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", false, true},
+		{"P2PKH", CryptoNetworkBitcoin, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", false, true},
+		{"P2SH", CryptoNetworkBitcoin, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", false, true},
+		{"checksum bech32", CryptoNetworkBitcoin, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", false, false},
+		{"bech32 com maiúsculas e minúsculas", CryptoNetworkBitcoin, "bc1qw508d6qejxtdg4y5r3zarvarY0c5xw7kv8f3t4", false, false},
+		{"checksum Base58Check", CryptoNetworkBitcoin, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", false, false},
+		{"rede de teste em produção", CryptoNetworkBitcoin, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false, false},
+		{"rede de teste com testnet", CryptoNetworkBitcoin, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", true, true},
+		{"EIP-55", CryptoNetworkEthereum, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false, true},
+		{"Ethereum sem checksum", CryptoNetworkEthereum, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false, true},
+		{"checksum EIP-55", CryptoNetworkEthereum, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeaEd", false, false},
+		{"Ethereum truncado", CryptoNetworkEthereum, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", false, false},
+		{"endereço Bitcoin na rede Ethereum", CryptoNetworkEthereum, testBTCPayerAddress, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCryptoAddress(tt.network, tt.address, tt.testnet)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrCryptoAddressInvalid)
+			}
+		})
+	}
+}
+
+func TestCryptoPaymentRateLockAndConfirmations(t *testing.T) {
+	ctx := context.Background()
+	service, provider, clock := newTestCryptoPaymentService(t, testBTCDepositAddress,
+		"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2")
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	service.SetWebhookDeliveryService(webhooks)
+	btc := CryptoPaymentDetails{Asset: "btc", PayerAddress: testBTCPayerAddress}
+
+	payment, err := service.Create(ctx, testCryptoRequest("tx-crypto-1", RegionUSA, "USD", 500, btc))
+	require.NoError(t, err)
+	assert.Equal(t, CryptoPaymentStatusAwaitingDeposit, payment.Status)
+	assert.Equal(t, 50000.0, payment.Rate)
+	assert.Equal(t, 0.01, payment.CryptoAmount)
+	assert.Equal(t, testBTCDepositAddress, payment.DepositAddress)
+	assert.Equal(t, clock.Add(DefaultCryptoQuoteWindow), payment.ExpiresAt)
+	assert.Equal(t, 3, payment.RequiredConfirmations)
+	assert.Equal(t, TravelRuleStatusNotRequired, payment.TravelRule.Status)
+
+	// A cotação fica bloqueada durante a janela, mesmo que o preço de referência mude
+	service.rates = StaticCryptoRates{"BTC/USD": 40000}
+	*clock = clock.Add(5 * time.Minute)
+	provider.setTransfers(testBTCDepositAddress, BlockchainTransfer{TxHash: "a1", Amount: 0.01, Confirmations: 1, SeenAt: *clock})
+	changed := service.Track(ctx)
+	require.Len(t, changed, 1)
+	assert.Equal(t, CryptoPaymentStatusConfirming, changed[0].Status)
+	assert.Equal(t, 0.01, changed[0].CryptoAmount)
+
+	// A mesma transação retorna o pagamento em curso em vez de nova cotação
+	again, err := service.Create(ctx, testCryptoRequest("tx-crypto-1", RegionUSA, "USD", 500, btc))
+	require.NoError(t, err)
+	assert.Equal(t, payment.ID, again.ID)
+
+	// Sem novas confirmações o estado não muda; com as confirmações exigidas o pagamento é concluído,
+	// mesmo depois do fim da janela
+	assert.Empty(t, service.Track(ctx))
+	seenAt := *clock
+	*clock = clock.Add(30 * time.Minute)
+	provider.setTransfers(testBTCDepositAddress, BlockchainTransfer{TxHash: "a1", Amount: 0.01, Confirmations: 3, SeenAt: seenAt})
+	changed = service.Track(ctx)
+	require.Len(t, changed, 1)
+	assert.Equal(t, CryptoPaymentStatusConfirmed, changed[0].Status)
+	assert.Equal(t, []string{"a1"}, changed[0].TxHashes)
+	require.NotNil(t, changed[0].ConfirmedAt)
+
+	// Depósito parcial na janela: pagamento abaixo do valor cotado quando a janela termina
+	service.rates = StaticCryptoRates{"BTC/USD": 50000}
+	underpaid, err := service.Create(ctx, testCryptoRequest("tx-crypto-2", RegionUSA, "USD", 200, btc))
+	require.NoError(t, err)
+	provider.setTransfers(underpaid.DepositAddress, BlockchainTransfer{TxHash: "b1", Amount: 0.003, Confirmations: 6, SeenAt: clock.Add(time.Minute)})
+	assert.Empty(t, service.Track(ctx))
+	*clock = clock.Add(16 * time.Minute)
+	changed = service.Track(ctx)
+	require.Len(t, changed, 1)
+	assert.Equal(t, CryptoPaymentStatusUnderpaid, changed[0].Status)
+	assert.Equal(t, 0.003, changed[0].ReceivedAmount)
+
+	// Depósito observado depois do fim da janela não é creditado à cotação bloqueada
+	expired, err := service.Create(ctx, testCryptoRequest("tx-crypto-3", RegionUSA, "USD", 200, btc))
+	require.NoError(t, err)
+	*clock = clock.Add(20 * time.Minute)
+	provider.setTransfers(expired.DepositAddress, BlockchainTransfer{TxHash: "c1", Amount: 0.004, Confirmations: 1, SeenAt: *clock})
+	changed = service.Track(ctx)
+	require.Len(t, changed, 1)
+	assert.Equal(t, CryptoPaymentStatusExpired, changed[0].Status)
+
+	// O comerciante é notificado da conclusão, do pagamento abaixo do valor e da expiração
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	events := make(map[string]WebhookEvent)
+	for _, delivery := range list {
+		events[delivery.Event.TransactionID] = delivery.Event
+	}
+	require.Len(t, events, 3)
+	assert.Equal(t, WebhookEventTransactionCompleted, events["tx-crypto-1"].EventType)
+	assert.Equal(t, "a1", events["tx-crypto-1"].ProcessorRef)
+	assert.Equal(t, PaymentMethodCrypto, events["tx-crypto-1"].PaymentMethod)
+	assert.Equal(t, WebhookEventTransactionFailed, events["tx-crypto-2"].EventType)
+	assert.Equal(t, ErrCryptoUnderpaid.Error(), events["tx-crypto-2"].Reason)
+	assert.Equal(t, WebhookEventTransactionFailed, events["tx-crypto-3"].EventType)
+	assert.Equal(t, ErrCryptoQuoteExpired.Error(), events["tx-crypto-3"].Reason)
+
+	// Uma transação expirada pode receber nova cotação
+	requoted, err := service.Create(ctx, testCryptoRequest("tx-crypto-3", RegionUSA, "USD", 200, btc))
+	require.NoError(t, err)
+	assert.NotEqual(t, expired.ID, requoted.ID)
+	assert.Equal(t, CryptoPaymentStatusAwaitingDeposit, requoted.Status)
+
+	// Carteira do pagador inválida e ativo não suportado são recusados antes da cotação
+	_, err = service.Create(ctx, testCryptoRequest("tx-crypto-4", RegionUSA, "USD", 100,
+		CryptoPaymentDetails{Asset: "BTC", PayerAddress: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"}))
+	assert.ErrorIs(t, err, ErrCryptoPaymentRejected)
+	assert.ErrorIs(t, err, ErrCryptoAddressInvalid)
+	_, err = service.Create(ctx, testCryptoRequest("tx-crypto-5", RegionUSA, "USD", 100,
+		CryptoPaymentDetails{Asset: "DOGE", PayerAddress: testBTCPayerAddress}))
+	assert.ErrorIs(t, err, ErrCryptoAssetUnsupported)
+	_, err = service.Get(ctx, "tenant-2", payment.ID)
+	assert.ErrorIs(t, err, ErrCryptoPaymentNotFound)
+}
+
+func TestCryptoPaymentTravelRule(t *testing.T) {
+	ctx := context.Background()
+	selfHostedDeposit := "0x52908400098527886e0f7030069857d2e4169ee7"
+	service, provider, clock := newTestCryptoPaymentService(t, "0x8617e340b3d01fa5f11f306f4090fd50e238070d",
+		testETHDepositAddress, selfHostedDeposit, "0xde709f2102306220921060314715629080e2fb77")
+
+	request := func(id, region string, amount float64, details CryptoPaymentDetails) *PaymentRequest {
+		details.Asset = "ETH"
+		details.PayerAddress = testETHPayerAddress
+		return testCryptoRequest(id, region, "USD", amount, details)
+	}
+	originator := &TravelRuleParty{Name: "Maria Silva", DateOfBirth: "1985-04-12", Country: "US"}
+
+	// Abaixo do limiar dos EUA (3000 USD) os dados do ordenante não são exigidos
+	payment, err := service.Create(ctx, request("tx-tr-1", RegionUSA, 2500, CryptoPaymentDetails{}))
+	require.NoError(t, err)
+	assert.Equal(t, TravelRuleStatusNotRequired, payment.TravelRule.Status)
+	assert.Equal(t, 1.0, payment.CryptoAmount)
+
+	_, err = service.Create(ctx, request("tx-tr-2", RegionUSA, 3500, CryptoPaymentDetails{}))
+	assert.ErrorIs(t, err, ErrCryptoPaymentRejected)
+	assert.ErrorIs(t, err, ErrTravelRuleDataMissing)
+
+	// Carteira num VASP: sem serviço de troca configurado a transferência é recusada
+	vasp := CryptoPaymentDetails{PayerVASPID: "LEI-EXCHANGE-001", Originator: originator}
+	_, err = service.Create(ctx, request("tx-tr-3", RegionUSA, 3500, vasp))
+	assert.ErrorIs(t, err, ErrTravelRuleUnavailable)
+
+	exchanger := &fakeTravelRuleExchanger{}
+	service.travelRule = exchanger
+	payment, err = service.Create(ctx, request("tx-tr-3", RegionUSA, 3500, vasp))
+	require.NoError(t, err)
+	assert.Equal(t, TravelRuleStatusExchanged, payment.TravelRule.Status)
+	assert.Equal(t, "TRP-1", payment.TravelRule.Reference)
+	require.Len(t, exchanger.messages, 1)
+	message := exchanger.messages[0]
+	assert.Equal(t, payment.ID, message.TransferID)
+	assert.Equal(t, 1.4, message.Amount)
+	assert.Equal(t, "Maria Silva", message.Originator.Name)
+	assert.Equal(t, testETHPayerAddress, message.Originator.WalletAddress)
+	assert.Equal(t, "LEI-EXCHANGE-001", message.Originator.VASPID)
+	assert.Equal(t, testETHDepositAddress, message.Beneficiary.WalletAddress)
+	assert.Equal(t, "LEI-INNOVABIZ-TEST", message.Beneficiary.VASPID)
+
+	// Carteira própria: exige prova de controlo da carteira
+	_, err = service.Create(ctx, request("tx-tr-4", RegionUSA, 3500, CryptoPaymentDetails{Originator: originator}))
+	assert.ErrorIs(t, err, ErrTravelRuleDataMissing)
+	payment, err = service.Create(ctx, request("tx-tr-4", RegionUSA, 3500,
+		CryptoPaymentDetails{Originator: originator, OwnershipProof: "0xsignature"}))
+	require.NoError(t, err)
+	assert.Equal(t, TravelRuleStatusSelfHosted, payment.TravelRule.Status)
+	assert.Len(t, exchanger.messages, 1)
+
+	// Com a travel rule aplicada, o depósito de outra carteira não é creditado
+	provider.setTransfers(selfHostedDeposit, BlockchainTransfer{
+		TxHash: "0xabc", FromAddress: "0x27b1fdb04752bbc536007a920d24acb045561c26", Amount: 1.4, Confirmations: 12, SeenAt: *clock,
+	})
+	assert.Empty(t, service.Track(ctx))
+	current, err := service.Get(ctx, "tenant-1", payment.ID)
+	require.NoError(t, err)
+	assert.Zero(t, current.ReceivedAmount)
+
+	// Na UE a travel rule aplica-se a qualquer valor, convertido para EUR à cotação do ativo
+	_, err = service.Create(ctx, request("tx-tr-5", RegionEU, 100, CryptoPaymentDetails{}))
+	assert.ErrorIs(t, err, ErrTravelRuleDataMissing)
+	payment, err = service.Create(ctx, request("tx-tr-5", RegionEU, 100,
+		CryptoPaymentDetails{Originator: originator, OwnershipProof: "0xsignature"}))
+	require.NoError(t, err)
+	assert.Equal(t, 92.0, payment.TravelRule.Value)
+	assert.Equal(t, "EUR", payment.TravelRule.Threshold.Currency)
+}
+
+func TestProcessPaymentCryptoPending(t *testing.T) {
+	ctx := context.Background()
+	connector, _ := newTestConnector(t)
+	service, _, _ := newTestCryptoPaymentService(t, testBTCDepositAddress)
+	connector.SetCryptoPaymentService(service)
+
+	response, err := connector.ProcessPayment(ctx, testCryptoRequest("tx-1", RegionUSA, "USD", 500,
+		CryptoPaymentDetails{Asset: "BTC", PayerAddress: testBTCPayerAddress}))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusPending, response.Status)
+	assert.Equal(t, "cripto_aguarda_deposito", response.StatusCode)
+	require.Contains(t, response.Metadata, "crypto_payment_id")
+	assert.Equal(t, testBTCDepositAddress, response.Metadata["crypto_deposit_address"])
+	assert.Equal(t, 0.01, response.Metadata["crypto_amount"])
+
+	payment, err := service.Get(ctx, "tenant-1", response.Metadata["crypto_payment_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", payment.TransactionID)
+
+	// A carteira inválida recusa o pagamento sem endereço de depósito
+	response, err = connector.ProcessPayment(ctx, testCryptoRequest("tx-2", RegionUSA, "USD", 500,
+		CryptoPaymentDetails{Asset: "BTC", PayerAddress: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"}))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "cripto_recusado", response.StatusCode)
+	assert.NotContains(t, response.Metadata, "crypto_payment_id")
+}
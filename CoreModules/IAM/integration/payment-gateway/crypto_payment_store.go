@@ -0,0 +1,107 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// CryptoPaymentStore define a persistência dos pagamentos em criptoativos
+type CryptoPaymentStore interface {
+	// SaveCryptoPayment grava o pagamento, substituindo o estado anterior
+	SaveCryptoPayment(ctx context.Context, payment *CryptoPayment) error
+
+	// GetCryptoPayment recupera o pagamento do tenant; retorna ErrCryptoPaymentNotFound quando não existe
+	GetCryptoPayment(ctx context.Context, tenantID, id string) (*CryptoPayment, error)
+
+	// GetCryptoPaymentByTransaction recupera o último pagamento da transação do tenant
+	GetCryptoPaymentByTransaction(ctx context.Context, tenantID, transactionID string) (*CryptoPayment, error)
+
+	// ListPendingCryptoPayments lista os pagamentos a aguardar o depósito ou as confirmações
+	ListPendingCryptoPayments(ctx context.Context) ([]*CryptoPayment, error)
+}
+
+// InMemoryCryptoPaymentStore armazena os pagamentos em criptoativos em memória
+type InMemoryCryptoPaymentStore struct {
+	payments      map[string]*CryptoPayment
+	byTransaction map[string]string
+	mutex         sync.RWMutex
+}
+
+// NewInMemoryCryptoPaymentStore cria um novo armazenamento em memória
+func NewInMemoryCryptoPaymentStore() *InMemoryCryptoPaymentStore {
+	return &InMemoryCryptoPaymentStore{
+		payments:      make(map[string]*CryptoPayment),
+		byTransaction: make(map[string]string),
+	}
+}
+
+// SaveCryptoPayment grava uma cópia do pagamento
+func (s *InMemoryCryptoPaymentStore) SaveCryptoPayment(ctx context.Context, payment *CryptoPayment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.payments[payment.ID] = copyCryptoPayment(payment)
+	s.byTransaction[payment.TenantID+"/"+payment.TransactionID] = payment.ID
+	return nil
+}
+
+// GetCryptoPayment retorna uma cópia do pagamento do tenant
+func (s *InMemoryCryptoPaymentStore) GetCryptoPayment(ctx context.Context, tenantID, id string) (*CryptoPayment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	payment, ok := s.payments[id]
+	if !ok || payment.TenantID != tenantID {
+		return nil, ErrCryptoPaymentNotFound
+	}
+	return copyCryptoPayment(payment), nil
+}
+
+// GetCryptoPaymentByTransaction retorna uma cópia do último pagamento da transação
+func (s *InMemoryCryptoPaymentStore) GetCryptoPaymentByTransaction(ctx context.Context, tenantID, transactionID string) (*CryptoPayment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	id, ok := s.byTransaction[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrCryptoPaymentNotFound
+	}
+	return copyCryptoPayment(s.payments[id]), nil
+}
+
+// ListPendingCryptoPayments lista cópias dos pagamentos em curso
+func (s *InMemoryCryptoPaymentStore) ListPendingCryptoPayments(ctx context.Context) ([]*CryptoPayment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var pending []*CryptoPayment
+	for _, payment := range s.payments {
+		if payment.Status == CryptoPaymentStatusAwaitingDeposit || payment.Status == CryptoPaymentStatusConfirming {
+			pending = append(pending, copyCryptoPayment(payment))
+		}
+	}
+	return pending, nil
+}
+
+// copyCryptoPayment copia o pagamento, as transferências e os dados da travel rule
+func copyCryptoPayment(payment *CryptoPayment) *CryptoPayment {
+	copied := *payment
+	copied.TxHashes = append([]string(nil), payment.TxHashes...)
+	if payment.ConfirmedAt != nil {
+		confirmedAt := *payment.ConfirmedAt
+		copied.ConfirmedAt = &confirmedAt
+	}
+	if payment.TravelRule.Originator != nil {
+		originator := *payment.TravelRule.Originator
+		copied.TravelRule.Originator = &originator
+	}
+	if payment.TravelRule.Beneficiary != nil {
+		beneficiary := *payment.TravelRule.Beneficiary
+		copied.TravelRule.Beneficiary = &beneficiary
+	}
+	if payment.TravelRule.ExchangedAt != nil {
+		exchangedAt := *payment.TravelRule.ExchangedAt
+		copied.TravelRule.ExchangedAt = &exchangedAt
+	}
+	return &copied
+}
@@ -0,0 +1,215 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// BlockchainProvider atribui endereços de depósito e acompanha as transferências de uma rede,
+// através de um nó próprio ou de um fornecedor de infraestrutura
+type BlockchainProvider interface {
+	// DepositAddress atribui um endereço de depósito dedicado ao pagamento indicado em reference
+	DepositAddress(ctx context.Context, asset CryptoAsset, reference string) (string, error)
+
+	// Transfers lista as transferências do ativo recebidas no endereço
+	Transfers(ctx context.Context, asset CryptoAsset, address string) ([]BlockchainTransfer, error)
+}
+
+// CryptoRateSource fornece o preço de um criptoativo numa moeda fiduciária
+type CryptoRateSource interface {
+	Rate(ctx context.Context, asset, currency string) (float64, error)
+}
+
+// StaticCryptoRates são os preços de referência da configuração por par "BTC/USD"
+type StaticCryptoRates map[string]float64
+
+// Rate retorna o preço configurado para o par
+func (r StaticCryptoRates) Rate(ctx context.Context, asset, currency string) (float64, error) {
+	rate, exists := r[asset+"/"+currency]
+	if !exists || rate <= 0 {
+		return 0, fmt.Errorf("%w: %s/%s", ErrCryptoRateUnavailable, asset, currency)
+	}
+	return rate, nil
+}
+
+// HTTPBlockchainProvider consulta a API HTTP do nó ou do fornecedor de uma rede
+type HTTPBlockchainProvider struct {
+	network    string
+	config     CryptoProviderConfig
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPBlockchainProvider cria o cliente do fornecedor configurado para a rede
+func NewHTTPBlockchainProvider(network string, provider CryptoProviderConfig, config CryptoPaymentConfig) *HTTPBlockchainProvider {
+	timeout := config.ProviderTimeout
+	if timeout <= 0 {
+		timeout = DefaultCryptoProviderTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPBlockchainProvider{
+		network:    network,
+		config:     provider,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// DepositAddress pede um endereço de depósito dedicado ao pagamento
+// O pagamento é a chave de idempotência, para que as novas tentativas não atribuam outro endereço
+func (p *HTTPBlockchainProvider) DepositAddress(ctx context.Context, asset CryptoAsset, reference string) (string, error) {
+	payload, err := json.Marshal(map[string]string{
+		"asset":     asset.Symbol,
+		"contract":  asset.Contract,
+		"reference": reference,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Address string `json:"address"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/addresses", payload, reference, &result); err != nil {
+		return "", err
+	}
+	if result.Address == "" {
+		return "", fmt.Errorf("resposta inválida do fornecedor da rede %s", p.network)
+	}
+	return result.Address, nil
+}
+
+// Transfers lista as transferências do ativo recebidas no endereço
+func (p *HTTPBlockchainProvider) Transfers(ctx context.Context, asset CryptoAsset, address string) ([]BlockchainTransfer, error) {
+	var result struct {
+		Transfers []BlockchainTransfer `json:"transfers"`
+	}
+	path := "/addresses/" + url.PathEscape(address) + "/transfers?asset=" + url.QueryEscape(asset.Symbol)
+	if err := p.do(ctx, http.MethodGet, path, nil, "", &result); err != nil {
+		return nil, err
+	}
+	return result.Transfers, nil
+}
+
+// do envia o pedido ao fornecedor e decodifica a resposta JSON
+func (p *HTTPBlockchainProvider) do(ctx context.Context, method, path string, payload []byte, idempotencyKey string, result interface{}) error {
+	resp, err := resilience.DoHTTP(ctx, p.httpClient, p.policy, func(ctx context.Context) (*http.Request, error) {
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(p.config.Endpoint, "/")+path, body)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if idempotencyKey != "" {
+			httpReq.Header.Set(resilience.IdempotencyKeyHeader, idempotencyKey)
+		}
+		if p.config.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return fmt.Errorf("fornecedor da rede %s indisponível: %w", p.network, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("fornecedor da rede %s indisponível: %w", p.network, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("fornecedor da rede %s recusou o pedido: HTTP %d", p.network, resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("resposta inválida do fornecedor da rede %s", p.network)
+	}
+	return nil
+}
+
+// TravelRuleExchanger troca os dados da travel rule com o VASP da contraparte (TRP, TRISA ou OpenVASP)
+type TravelRuleExchanger interface {
+	// Exchange envia a mensagem e retorna a referência da troca; a recusa do VASP da contraparte é um erro
+	Exchange(ctx context.Context, message TravelRuleMessage) (string, error)
+}
+
+// HTTPTravelRuleExchanger envia os dados da travel rule ao serviço de troca entre VASPs
+type HTTPTravelRuleExchanger struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPTravelRuleExchanger cria o cliente do serviço configurado em TravelRuleEndpoint
+func NewHTTPTravelRuleExchanger(config CryptoPaymentConfig) *HTTPTravelRuleExchanger {
+	timeout := config.ProviderTimeout
+	if timeout <= 0 {
+		timeout = DefaultCryptoProviderTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPTravelRuleExchanger{
+		endpoint:   config.TravelRuleEndpoint,
+		apiKey:     config.TravelRuleAPIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Exchange envia um POST JSON com a transferência como chave de idempotência
+func (e *HTTPTravelRuleExchanger) Exchange(ctx context.Context, message TravelRuleMessage) (string, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := resilience.DoHTTP(ctx, e.httpClient, e.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, message.TransferID)
+		if e.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("serviço de travel rule indisponível: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("serviço de travel rule indisponível: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("serviço de travel rule recusou o pedido: HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Reference == "" {
+		return "", fmt.Errorf("resposta inválida do serviço de travel rule")
+	}
+	if result.Status == "rejected" {
+		return "", fmt.Errorf("VASP do ordenante recusou a troca de dados: %s", result.Reason)
+	}
+	return result.Reference, nil
+}
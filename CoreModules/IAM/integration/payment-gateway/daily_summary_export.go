@@ -0,0 +1,165 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Tipos de conteúdo dos ficheiros exportados
+const (
+	summaryContentTypeCSV     = "text/csv"
+	summaryContentTypeParquet = "application/vnd.apache.parquet"
+)
+
+// SummaryExportStorage grava os ficheiros exportados num armazenamento de objetos (ex.: S3)
+type SummaryExportStorage interface {
+	PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error
+}
+
+// LocalSummaryExportStorage grava os ficheiros exportados no sistema de ficheiros local
+type LocalSummaryExportStorage struct {
+	baseDir string
+}
+
+// NewLocalSummaryExportStorage cria uma nova instância do armazenamento local
+func NewLocalSummaryExportStorage(baseDir string) (*LocalSummaryExportStorage, error) {
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, fmt.Errorf("falha ao criar diretório de exportações: %w", err)
+	}
+	return &LocalSummaryExportStorage{baseDir: baseDir}, nil
+}
+
+// PutObject grava o conteúdo do ficheiro; os metadados não são persistidos localmente
+func (s *LocalSummaryExportStorage) PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error {
+	path := filepath.Join(s.baseDir, filepath.Clean("/"+key))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("falha ao criar diretório da exportação: %w", err)
+	}
+
+	if err := os.WriteFile(path, body, 0600); err != nil {
+		return fmt.Errorf("falha ao gravar exportação: %w", err)
+	}
+
+	return nil
+}
+
+// dailySummaryColumnNames são as colunas dos ficheiros exportados, comuns a CSV e Parquet
+var dailySummaryColumnNames = []string{
+	"tenant_id", "summary_date", "region_code", "merchant_id", "currency",
+	"transaction_count", "approved_count", "denied_count", "challenged_count", "error_count", "approval_rate",
+	"approved_volume", "refund_count", "refund_volume", "dispute_count", "dispute_volume", "net_volume", "generated_at",
+}
+
+// dailySummaryParquetRow é a linha Parquet de um resumo; generated_at em milissegundos Unix (UTC)
+type dailySummaryParquetRow struct {
+	TenantID         string  `parquet:"tenant_id"`
+	SummaryDate      string  `parquet:"summary_date"`
+	RegionCode       string  `parquet:"region_code"`
+	MerchantID       string  `parquet:"merchant_id"`
+	Currency         string  `parquet:"currency"`
+	TransactionCount int64   `parquet:"transaction_count"`
+	ApprovedCount    int64   `parquet:"approved_count"`
+	DeniedCount      int64   `parquet:"denied_count"`
+	ChallengedCount  int64   `parquet:"challenged_count"`
+	ErrorCount       int64   `parquet:"error_count"`
+	ApprovalRate     float64 `parquet:"approval_rate"`
+	ApprovedVolume   float64 `parquet:"approved_volume"`
+	RefundCount      int64   `parquet:"refund_count"`
+	RefundVolume     float64 `parquet:"refund_volume"`
+	DisputeCount     int64   `parquet:"dispute_count"`
+	DisputeVolume    float64 `parquet:"dispute_volume"`
+	NetVolume        float64 `parquet:"net_volume"`
+	GeneratedAt      int64   `parquet:"generated_at"`
+}
+
+// encodeDailySummaries codifica os resumos no formato pedido e retorna o conteúdo e o tipo
+func encodeDailySummaries(format string, summaries []*DailySummary) ([]byte, string, error) {
+	switch format {
+	case SummaryExportCSV:
+		body, err := encodeDailySummariesCSV(summaries)
+		return body, summaryContentTypeCSV, err
+	case SummaryExportParquet:
+		body, err := encodeDailySummariesParquet(summaries)
+		return body, summaryContentTypeParquet, err
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrSummaryExportFormat, format)
+	}
+}
+
+// encodeDailySummariesCSV codifica os resumos em CSV com cabeçalho
+func encodeDailySummariesCSV(summaries []*DailySummary) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(dailySummaryColumnNames); err != nil {
+		return nil, fmt.Errorf("falha ao gravar cabeçalho CSV: %w", err)
+	}
+
+	formatInt := func(v int64) string { return strconv.FormatInt(v, 10) }
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, s := range summaries {
+		record := []string{
+			s.TenantID, s.SummaryDate, s.RegionCode, s.MerchantID, s.Currency,
+			formatInt(s.TransactionCount), formatInt(s.ApprovedCount), formatInt(s.DeniedCount),
+			formatInt(s.ChallengedCount), formatInt(s.ErrorCount), formatFloat(s.ApprovalRate),
+			formatFloat(s.ApprovedVolume), formatInt(s.RefundCount), formatFloat(s.RefundVolume),
+			formatInt(s.DisputeCount), formatFloat(s.DisputeVolume), formatFloat(s.NetVolume),
+			s.GeneratedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("falha ao gravar linha CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("falha ao gravar CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeDailySummariesParquet codifica os resumos em Parquet
+func encodeDailySummariesParquet(summaries []*DailySummary) ([]byte, error) {
+	rows := make([]dailySummaryParquetRow, 0, len(summaries))
+	for _, s := range summaries {
+		rows = append(rows, dailySummaryParquetRow{
+			TenantID:         s.TenantID,
+			SummaryDate:      s.SummaryDate,
+			RegionCode:       s.RegionCode,
+			MerchantID:       s.MerchantID,
+			Currency:         s.Currency,
+			TransactionCount: s.TransactionCount,
+			ApprovedCount:    s.ApprovedCount,
+			DeniedCount:      s.DeniedCount,
+			ChallengedCount:  s.ChallengedCount,
+			ErrorCount:       s.ErrorCount,
+			ApprovalRate:     s.ApprovalRate,
+			ApprovedVolume:   s.ApprovedVolume,
+			RefundCount:      s.RefundCount,
+			RefundVolume:     s.RefundVolume,
+			DisputeCount:     s.DisputeCount,
+			DisputeVolume:    s.DisputeVolume,
+			NetVolume:        s.NetVolume,
+			GeneratedAt:      s.GeneratedAt.UnixMilli(),
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := parquet.NewGenericWriter[dailySummaryParquetRow](&buf)
+	if _, err := writer.Write(rows); err != nil {
+		return nil, fmt.Errorf("falha ao gravar linhas Parquet: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("falha ao finalizar Parquet: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
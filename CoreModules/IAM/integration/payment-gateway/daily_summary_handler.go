@@ -0,0 +1,95 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// DailySummaryHandler expõe a API HTTP de consulta dos resumos diários para dashboards
+type DailySummaryHandler struct {
+	service *DailySummaryService
+}
+
+// NewDailySummaryHandler cria uma nova instância do DailySummaryHandler
+func NewDailySummaryHandler(service *DailySummaryService) *DailySummaryHandler {
+	return &DailySummaryHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *DailySummaryHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/reports/daily-summaries", h.ListSummaries).Methods(http.MethodGet)
+	router.HandleFunc("/reports/daily-summaries/{date}/exports", h.ListExports).Methods(http.MethodGet)
+	router.HandleFunc("/reports/daily-summaries/{date}/exports", h.CreateExport).Methods(http.MethodPost)
+}
+
+// ListSummaries retorna os resumos do tenant no período (AAAA-MM-DD, datas inclusivas),
+// opcionalmente filtrados por mercado e comerciante
+func (h *DailySummaryHandler) ListSummaries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := DailySummaryFilter{
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		RegionCode: query.Get("region_code"),
+		MerchantID: query.Get("merchant_id"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro limit inválido")
+			return
+		}
+		filter.Limit = value
+	}
+
+	summaries, err := h.service.ListSummaries(r.Context(), r.Header.Get("X-Tenant-ID"), filter)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summaries)
+}
+
+// ListExports retorna os ficheiros exportados do tenant para o dia
+func (h *DailySummaryHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.service.ListExports(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["date"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, exports)
+}
+
+// CreateExport exporta novamente os resumos do dia do tenant no formato indicado (csv por omissão)
+func (h *DailySummaryHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = SummaryExportCSV
+	}
+
+	export, err := h.service.ExportDay(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["date"], format)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, export)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *DailySummaryHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrDailySummaryFilterInvalid), errors.Is(err, ErrSummaryExportFormat):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrDailySummaryNotClosed):
+		respondWithError(w, http.StatusConflict, "day_not_closed", err.Error())
+	case errors.Is(err, ErrSummaryExportDisabled):
+		respondWithError(w, http.StatusServiceUnavailable, "export_disabled", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "daily_summary_error", err.Error())
+	}
+}
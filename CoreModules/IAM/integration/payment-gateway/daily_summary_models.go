@@ -0,0 +1,131 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Tipos de lançamento do livro diário de transações
+const (
+	LedgerEntryPayment = "payment"
+	LedgerEntryRefund  = "refund"
+	LedgerEntryDispute = "dispute"
+)
+
+// Formatos de exportação dos resumos diários
+const (
+	SummaryExportCSV     = "csv"
+	SummaryExportParquet = "parquet"
+)
+
+// Valores padrão do fecho diário
+const (
+	DefaultDailySummaryCloseDelay  = 30 * time.Minute
+	DefaultDailySummaryCheckPeriod = 5 * time.Minute
+	DefaultDailySummaryListLimit   = 500
+)
+
+// Formato das datas dos resumos diários (AAAA-MM-DD)
+const SummaryDateLayout = "2006-01-02"
+
+// Erros do serviço de resumos diários
+var (
+	ErrDailySummaryFilterInvalid = errors.New("filtro de resumos diários inválido")
+	ErrSummaryExportFormat       = errors.New("formato de exportação não suportado")
+	ErrLedgerEntryInvalid        = errors.New("lançamento do livro diário inválido")
+	ErrDailySummaryNotClosed     = errors.New("dia ainda não fechado")
+	ErrSummaryExportDisabled     = errors.New("armazenamento de exportações não configurado")
+)
+
+// LedgerEntry é um lançamento do livro diário usado no fecho: um pagamento processado,
+// um reembolso ou uma disputa (chargeback) recebida
+type LedgerEntry struct {
+	EntryID       string    `json:"entry_id" db:"entry_id"`
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	RegionCode    string    `json:"region_code" db:"region_code"`
+	MerchantID    string    `json:"merchant_id" db:"merchant_id"`
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	EntryType     string    `json:"entry_type" db:"entry_type"`   // payment, refund ou dispute
+	Status        string    `json:"status,omitempty" db:"status"` // Status final do pagamento
+	Amount        float64   `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	OccurredAt    time.Time `json:"occurred_at" db:"occurred_at"`
+}
+
+// DailySummary é o resumo financeiro de um dia por tenant, mercado, comerciante e moeda
+type DailySummary struct {
+	TenantID         string    `json:"tenant_id" db:"tenant_id"`
+	SummaryDate      string    `json:"summary_date" db:"summary_date"` // AAAA-MM-DD, no fuso do fecho
+	RegionCode       string    `json:"region_code" db:"region_code"`
+	MerchantID       string    `json:"merchant_id" db:"merchant_id"`
+	Currency         string    `json:"currency" db:"currency"`
+	TransactionCount int64     `json:"transaction_count" db:"transaction_count"`
+	ApprovedCount    int64     `json:"approved_count" db:"approved_count"`
+	DeniedCount      int64     `json:"denied_count" db:"denied_count"`
+	ChallengedCount  int64     `json:"challenged_count" db:"challenged_count"`
+	ErrorCount       int64     `json:"error_count" db:"error_count"`
+	ApprovalRate     float64   `json:"approval_rate" db:"approval_rate"`
+	ApprovedVolume   float64   `json:"approved_volume" db:"approved_volume"`
+	RefundCount      int64     `json:"refund_count" db:"refund_count"`
+	RefundVolume     float64   `json:"refund_volume" db:"refund_volume"`
+	DisputeCount     int64     `json:"dispute_count" db:"dispute_count"`
+	DisputeVolume    float64   `json:"dispute_volume" db:"dispute_volume"`
+	NetVolume        float64   `json:"net_volume" db:"net_volume"` // Volume aprovado deduzido de reembolsos e disputas
+	GeneratedAt      time.Time `json:"generated_at" db:"generated_at"`
+}
+
+// DailySummaryFilter delimita a consulta de resumos de um tenant (datas inclusivas, AAAA-MM-DD)
+type DailySummaryFilter struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	RegionCode string `json:"region_code,omitempty"`
+	MerchantID string `json:"merchant_id,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// Validate verifica as datas e o limite do filtro
+func (f *DailySummaryFilter) Validate() error {
+	from, err := time.Parse(SummaryDateLayout, f.From)
+	if err != nil {
+		return ErrDailySummaryFilterInvalid
+	}
+	to, err := time.Parse(SummaryDateLayout, f.To)
+	if err != nil || to.Before(from) {
+		return ErrDailySummaryFilterInvalid
+	}
+	if f.Limit <= 0 || f.Limit > DefaultDailySummaryListLimit {
+		f.Limit = DefaultDailySummaryListLimit
+	}
+	return nil
+}
+
+// DailySummaryExport regista um ficheiro de resumos exportado para o armazenamento de objetos
+type DailySummaryExport struct {
+	ExportID     string    `json:"export_id" db:"export_id"`
+	TenantID     string    `json:"tenant_id" db:"tenant_id"`
+	SummaryDate  string    `json:"summary_date" db:"summary_date"`
+	Format       string    `json:"format" db:"format"`
+	ObjectKey    string    `json:"object_key" db:"object_key"`
+	SummaryCount int       `json:"summary_count" db:"summary_count"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	SHA256       string    `json:"sha256" db:"sha256"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// DailySummaryConfig contém configurações do fecho diário
+type DailySummaryConfig struct {
+	// Fuso em que os dias são delimitados (ex.: "Africa/Luanda"); vazio usa UTC
+	TimeZone string `json:"time_zone"`
+
+	// Tempo após a meia-noite a aguardar antes do fecho, para acolher lançamentos atrasados
+	CloseDelay time.Duration `json:"close_delay"`
+
+	// Intervalo entre verificações do agendador
+	CheckPeriod time.Duration `json:"check_period"`
+
+	// Formatos exportados em cada fecho (csv, parquet); vazio não exporta
+	ExportFormats []string `json:"export_formats"`
+
+	// Prefixo das chaves dos ficheiros no armazenamento de objetos
+	ExportPrefix string `json:"export_prefix"`
+}
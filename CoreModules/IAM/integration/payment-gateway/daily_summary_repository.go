@@ -0,0 +1,200 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Resumos gravados por instrução INSERT, abaixo do limite de parâmetros do PostgreSQL
+const dailySummaryInsertBatch = 1000
+
+// Colunas dos resumos diários, com a data formatada como AAAA-MM-DD
+const dailySummaryColumns = `
+	tenant_id, to_char(summary_date, 'YYYY-MM-DD') AS summary_date, region_code, merchant_id, currency,
+	transaction_count, approved_count, denied_count, challenged_count, error_count, approval_rate,
+	approved_volume, refund_count, refund_volume, dispute_count, dispute_volume, net_volume, generated_at`
+
+// PostgresDailySummaryStore implementa DailySummaryStore para PostgreSQL
+type PostgresDailySummaryStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresDailySummaryStore cria uma nova instância de PostgresDailySummaryStore
+func NewPostgresDailySummaryStore(db *sqlx.DB) *PostgresDailySummaryStore {
+	return &PostgresDailySummaryStore{db: db}
+}
+
+// AppendLedgerEntry grava um lançamento do livro diário; lançamentos repetidos são ignorados
+func (r *PostgresDailySummaryStore) AppendLedgerEntry(ctx context.Context, entry *LedgerEntry) error {
+	query := `
+		INSERT INTO payment_gateway.transaction_ledger (
+			entry_id, tenant_id, region_code, merchant_id, transaction_id,
+			entry_type, status, amount, currency, occurred_at
+		) VALUES (
+			:entry_id, :tenant_id, :region_code, :merchant_id, :transaction_id,
+			:entry_type, :status, :amount, :currency, :occurred_at
+		)
+		ON CONFLICT (entry_id) DO NOTHING
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, entry); err != nil {
+		return fmt.Errorf("falha ao gravar lançamento do livro diário: %w", err)
+	}
+
+	return nil
+}
+
+// ListLedgerEntries lista os lançamentos de todos os tenants no intervalo [from, to)
+func (r *PostgresDailySummaryStore) ListLedgerEntries(ctx context.Context, from, to time.Time) ([]*LedgerEntry, error) {
+	var entries []*LedgerEntry
+	query := `
+		SELECT entry_id, tenant_id, region_code, merchant_id, transaction_id,
+			entry_type, status, amount, currency, occurred_at
+		FROM payment_gateway.transaction_ledger
+		WHERE occurred_at >= $1 AND occurred_at < $2
+		ORDER BY occurred_at
+	`
+	if err := r.db.SelectContext(ctx, &entries, query, from, to); err != nil {
+		return nil, fmt.Errorf("falha ao listar lançamentos do livro diário: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ReplaceSummaries substitui os resumos do dia e regista o fecho numa única transação
+func (r *PostgresDailySummaryStore) ReplaceSummaries(ctx context.Context, summaryDate string, summaries []*DailySummary) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao iniciar transação do fecho diário: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM payment_gateway.daily_summaries WHERE summary_date = $1`, summaryDate); err != nil {
+		return fmt.Errorf("falha ao remover resumos anteriores do dia: %w", err)
+	}
+
+	insert := `
+		INSERT INTO payment_gateway.daily_summaries (
+			tenant_id, summary_date, region_code, merchant_id, currency,
+			transaction_count, approved_count, denied_count, challenged_count, error_count, approval_rate,
+			approved_volume, refund_count, refund_volume, dispute_count, dispute_volume, net_volume, generated_at
+		) VALUES (
+			:tenant_id, :summary_date, :region_code, :merchant_id, :currency,
+			:transaction_count, :approved_count, :denied_count, :challenged_count, :error_count, :approval_rate,
+			:approved_volume, :refund_count, :refund_volume, :dispute_count, :dispute_volume, :net_volume, :generated_at
+		)
+	`
+	for start := 0; start < len(summaries); start += dailySummaryInsertBatch {
+		end := start + dailySummaryInsertBatch
+		if end > len(summaries) {
+			end = len(summaries)
+		}
+		if _, err := tx.NamedExecContext(ctx, insert, summaries[start:end]); err != nil {
+			return fmt.Errorf("falha ao gravar resumos diários: %w", err)
+		}
+	}
+
+	run := `
+		INSERT INTO payment_gateway.daily_summary_runs (summary_date, summary_count, closed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (summary_date) DO UPDATE SET
+			summary_count = EXCLUDED.summary_count,
+			closed_at = EXCLUDED.closed_at
+	`
+	if _, err := tx.ExecContext(ctx, run, summaryDate, len(summaries)); err != nil {
+		return fmt.Errorf("falha ao registar fecho diário: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("falha ao confirmar fecho diário: %w", err)
+	}
+
+	return nil
+}
+
+// IsDayClosed indica se o fecho do dia já foi gravado
+func (r *PostgresDailySummaryStore) IsDayClosed(ctx context.Context, summaryDate string) (bool, error) {
+	var closed bool
+	query := `SELECT EXISTS (SELECT 1 FROM payment_gateway.daily_summary_runs WHERE summary_date = $1)`
+	if err := r.db.GetContext(ctx, &closed, query, summaryDate); err != nil {
+		return false, fmt.Errorf("falha ao verificar fecho diário: %w", err)
+	}
+
+	return closed, nil
+}
+
+// ListSummaries lista os resumos do tenant que satisfazem o filtro, por data e comerciante
+func (r *PostgresDailySummaryStore) ListSummaries(ctx context.Context, tenantID string, filter DailySummaryFilter) ([]*DailySummary, error) {
+	conditions := []string{"tenant_id = $1", "summary_date >= $2", "summary_date <= $3"}
+	args := []interface{}{tenantID, filter.From, filter.To}
+
+	if filter.RegionCode != "" {
+		args = append(args, filter.RegionCode)
+		conditions = append(conditions, fmt.Sprintf("region_code = $%d", len(args)))
+	}
+	if filter.MerchantID != "" {
+		args = append(args, filter.MerchantID)
+		conditions = append(conditions, fmt.Sprintf("merchant_id = $%d", len(args)))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultDailySummaryListLimit
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM payment_gateway.daily_summaries
+		WHERE %s
+		ORDER BY summary_date, region_code, merchant_id, currency
+		LIMIT $%d
+	`, dailySummaryColumns, strings.Join(conditions, " AND "), len(args))
+
+	var summaries []*DailySummary
+	if err := r.db.SelectContext(ctx, &summaries, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao listar resumos diários: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// SaveExport regista um ficheiro exportado
+func (r *PostgresDailySummaryStore) SaveExport(ctx context.Context, export *DailySummaryExport) error {
+	query := `
+		INSERT INTO payment_gateway.daily_summary_exports (
+			export_id, tenant_id, summary_date, format, object_key,
+			summary_count, size_bytes, sha256, created_at
+		) VALUES (
+			:export_id, :tenant_id, :summary_date, :format, :object_key,
+			:summary_count, :size_bytes, :sha256, :created_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, export); err != nil {
+		return fmt.Errorf("falha ao registar exportação de resumos: %w", err)
+	}
+
+	return nil
+}
+
+// ListExports lista os ficheiros exportados do tenant para o dia
+func (r *PostgresDailySummaryStore) ListExports(ctx context.Context, tenantID, summaryDate string) ([]*DailySummaryExport, error) {
+	var exports []*DailySummaryExport
+	query := `
+		SELECT export_id, tenant_id, to_char(summary_date, 'YYYY-MM-DD') AS summary_date, format, object_key,
+			summary_count, size_bytes, sha256, created_at
+		FROM payment_gateway.daily_summary_exports
+		WHERE tenant_id = $1 AND summary_date = $2
+		ORDER BY created_at DESC
+	`
+	if err := r.db.SelectContext(ctx, &exports, query, tenantID, summaryDate); err != nil {
+		return nil, fmt.Errorf("falha ao listar exportações de resumos: %w", err)
+	}
+
+	return exports, nil
+}
@@ -0,0 +1,429 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// DailySummaryService regista o livro diário de transações e produz, no fecho de cada dia,
+// os resumos por mercado e comerciante, exportando-os para o armazenamento de objetos
+type DailySummaryService struct {
+	config   DailySummaryConfig
+	store    DailySummaryStore
+	storage  SummaryExportStorage
+	location *time.Location
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewDailySummaryService cria o serviço de resumos diários
+// storage pode ser nil quando nenhum formato de exportação está configurado
+func NewDailySummaryService(config DailySummaryConfig, store DailySummaryStore, storage SummaryExportStorage) (*DailySummaryService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-daily-summary",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.CloseDelay <= 0 {
+		config.CloseDelay = DefaultDailySummaryCloseDelay
+	}
+	if config.CheckPeriod <= 0 {
+		config.CheckPeriod = DefaultDailySummaryCheckPeriod
+	}
+	for _, format := range config.ExportFormats {
+		if format != SummaryExportCSV && format != SummaryExportParquet {
+			return nil, fmt.Errorf("%w: %s", ErrSummaryExportFormat, format)
+		}
+	}
+	if len(config.ExportFormats) > 0 && storage == nil {
+		return nil, ErrSummaryExportDisabled
+	}
+
+	location := time.UTC
+	if config.TimeZone != "" {
+		location, err = time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("fuso horário do fecho diário inválido %q: %w", config.TimeZone, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DailySummaryService{
+		config:          config,
+		store:           store,
+		storage:         storage,
+		location:        location,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetClock substitui o relógio usado no agendamento do fecho e na data dos lançamentos
+func (s *DailySummaryService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Start inicia o agendador que fecha o dia anterior após o atraso configurado
+func (s *DailySummaryService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.CheckPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.closeDueDay(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Agendador de resumos diários iniciado", "time_zone", s.location.String())
+}
+
+// Stop interrompe o agendador
+func (s *DailySummaryService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// RecordPaymentOutcome regista no livro diário o resultado final de um pagamento processado
+func (s *DailySummaryService) RecordPaymentOutcome(ctx context.Context, req *PaymentRequest, resp *PaymentResponse) {
+	ctx, span := s.tracer.StartSpan(ctx, "DailySummaryService.RecordPaymentOutcome")
+	defer span.End()
+
+	// Pagamentos pendentes são registados quando o processamento terminar
+	if resp.Status == TransactionStatusPending {
+		return
+	}
+
+	entry := &LedgerEntry{
+		EntryID:       uuid.New().String(),
+		TenantID:      req.TenantID,
+		RegionCode:    req.RegionCode,
+		MerchantID:    req.MerchantID,
+		TransactionID: req.TransactionID,
+		EntryType:     LedgerEntryPayment,
+		Status:        resp.Status,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		OccurredAt:    s.now(),
+	}
+	if err := s.store.AppendLedgerEntry(ctx, entry); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao registar pagamento no livro diário",
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// RecordRefund regista no livro diário um reembolso sobre uma transação
+func (s *DailySummaryService) RecordRefund(ctx context.Context, entry *LedgerEntry) error {
+	return s.recordAdjustment(ctx, LedgerEntryRefund, entry)
+}
+
+// RecordDispute regista no livro diário uma disputa (chargeback) recebida sobre uma transação
+func (s *DailySummaryService) RecordDispute(ctx context.Context, entry *LedgerEntry) error {
+	return s.recordAdjustment(ctx, LedgerEntryDispute, entry)
+}
+
+// recordAdjustment valida e regista um reembolso ou uma disputa
+func (s *DailySummaryService) recordAdjustment(ctx context.Context, entryType string, entry *LedgerEntry) error {
+	ctx, span := s.tracer.StartSpan(ctx, "DailySummaryService.RecordAdjustment")
+	defer span.End()
+
+	if entry.TenantID == "" || entry.MerchantID == "" || entry.TransactionID == "" ||
+		entry.Currency == "" || entry.Amount <= 0 {
+		return ErrLedgerEntryInvalid
+	}
+
+	stored := *entry
+	stored.EntryType = entryType
+	stored.Status = ""
+	if stored.EntryID == "" {
+		stored.EntryID = uuid.New().String()
+	}
+	if stored.OccurredAt.IsZero() {
+		stored.OccurredAt = s.now()
+	}
+
+	if err := s.store.AppendLedgerEntry(ctx, &stored); err != nil {
+		return err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_ledger_adjustments_total", map[string]string{
+		"region": stored.RegionCode,
+		"type":   entryType,
+	})
+
+	return nil
+}
+
+// CloseDay agrega os lançamentos do dia (AAAA-MM-DD, no fuso configurado) em resumos por
+// tenant, mercado, comerciante e moeda, substitui os resumos anteriores e exporta-os
+// Fechar novamente um dia recalcula os resumos, incluindo lançamentos recebidos depois do fecho
+func (s *DailySummaryService) CloseDay(ctx context.Context, summaryDate string) ([]*DailySummary, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "DailySummaryService.CloseDay")
+	defer span.End()
+
+	from, err := time.ParseInLocation(SummaryDateLayout, summaryDate, s.location)
+	if err != nil {
+		return nil, ErrDailySummaryFilterInvalid
+	}
+
+	entries, err := s.store.ListLedgerEntries(ctx, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := aggregateLedgerEntries(summaryDate, entries, s.now())
+	if err := s.store.ReplaceSummaries(ctx, summaryDate, summaries); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_daily_summary_closes_total", map[string]string{})
+	s.logger.InfoWithContext(ctx, "Fecho diário concluído",
+		"summary_date", summaryDate,
+		"ledger_entries", len(entries),
+		"summaries", len(summaries))
+
+	// Falhas na exportação não invalidam o fecho; a exportação pode ser repetida via ExportDay
+	for _, tenantID := range summaryTenants(summaries) {
+		for _, format := range s.config.ExportFormats {
+			if _, err := s.ExportDay(ctx, tenantID, summaryDate, format); err != nil {
+				s.logger.WarnWithContext(ctx, "Falha ao exportar resumos diários",
+					"tenant_id", tenantID,
+					"summary_date", summaryDate,
+					"format", format,
+					"error", err.Error())
+			}
+		}
+	}
+
+	return summaries, nil
+}
+
+// ExportDay exporta os resumos de um dia fechado do tenant no formato pedido
+func (s *DailySummaryService) ExportDay(ctx context.Context, tenantID, summaryDate, format string) (*DailySummaryExport, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "DailySummaryService.ExportDay")
+	defer span.End()
+
+	if s.storage == nil {
+		return nil, ErrSummaryExportDisabled
+	}
+
+	closed, err := s.store.IsDayClosed(ctx, summaryDate)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, ErrDailySummaryNotClosed
+	}
+
+	// A exportação inclui todos os resumos do dia, sem o limite aplicado às consultas
+	summaries, err := s.store.ListSummaries(ctx, tenantID, DailySummaryFilter{From: summaryDate, To: summaryDate, Limit: math.MaxInt32})
+	if err != nil {
+		return nil, err
+	}
+
+	body, contentType, err := encodeDailySummaries(format, summaries)
+	if err != nil {
+		s.metricsRecorder.CounterInc("payment_gateway_daily_summary_exports_total", map[string]string{
+			"format": format,
+			"status": "error",
+		})
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	export := &DailySummaryExport{
+		ExportID:     uuid.New().String(),
+		TenantID:     tenantID,
+		SummaryDate:  summaryDate,
+		Format:       format,
+		SummaryCount: len(summaries),
+		SizeBytes:    int64(len(body)),
+		SHA256:       hex.EncodeToString(sum[:]),
+		CreatedAt:    s.now(),
+	}
+	export.ObjectKey = path.Join(strings.Trim(s.config.ExportPrefix, "/"), tenantID, summaryDate,
+		fmt.Sprintf("daily-summary-%s.%s", export.ExportID, format))
+
+	metadata := map[string]string{
+		"tenant-id":    tenantID,
+		"summary-date": summaryDate,
+		"sha256":       export.SHA256,
+	}
+	if err := s.storage.PutObject(ctx, export.ObjectKey, body, contentType, metadata); err != nil {
+		s.metricsRecorder.CounterInc("payment_gateway_daily_summary_exports_total", map[string]string{
+			"format": format,
+			"status": "error",
+		})
+		return nil, fmt.Errorf("falha ao gravar exportação no armazenamento: %w", err)
+	}
+
+	if err := s.store.SaveExport(ctx, export); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_daily_summary_exports_total", map[string]string{
+		"format": format,
+		"status": "success",
+	})
+	s.logger.InfoWithContext(ctx, "Resumos diários exportados",
+		"tenant_id", tenantID,
+		"summary_date", summaryDate,
+		"format", format,
+		"object_key", export.ObjectKey)
+
+	return export, nil
+}
+
+// ListSummaries lista os resumos do tenant para dashboards
+func (s *DailySummaryService) ListSummaries(ctx context.Context, tenantID string, filter DailySummaryFilter) ([]*DailySummary, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "DailySummaryService.ListSummaries")
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.store.ListSummaries(ctx, tenantID, filter)
+}
+
+// ListExports lista os ficheiros exportados do tenant para o dia
+func (s *DailySummaryService) ListExports(ctx context.Context, tenantID, summaryDate string) ([]*DailySummaryExport, error) {
+	if _, err := time.Parse(SummaryDateLayout, summaryDate); err != nil {
+		return nil, ErrDailySummaryFilterInvalid
+	}
+
+	return s.store.ListExports(ctx, tenantID, summaryDate)
+}
+
+// closeDueDay fecha o dia anterior, uma vez decorrido o atraso após a meia-noite, se ainda não estiver fechado
+func (s *DailySummaryService) closeDueDay(ctx context.Context) {
+	due := s.now().In(s.location).Add(-s.config.CloseDelay).AddDate(0, 0, -1).Format(SummaryDateLayout)
+
+	closed, err := s.store.IsDayClosed(ctx, due)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao verificar fecho diário",
+			"summary_date", due,
+			"error", err.Error())
+		return
+	}
+	if closed {
+		return
+	}
+
+	if _, err := s.CloseDay(ctx, due); err != nil {
+		s.metricsRecorder.CounterInc("payment_gateway_daily_summary_close_errors_total", map[string]string{})
+		s.logger.ErrorWithContext(ctx, "Falha no fecho diário",
+			"summary_date", due,
+			"error", err.Error())
+	}
+}
+
+// aggregateLedgerEntries agrega os lançamentos por tenant, mercado, comerciante e moeda
+func aggregateLedgerEntries(summaryDate string, entries []*LedgerEntry, generatedAt time.Time) []*DailySummary {
+	groups := make(map[string]*DailySummary)
+	for _, entry := range entries {
+		key := strings.Join([]string{entry.TenantID, entry.RegionCode, entry.MerchantID, entry.Currency}, "\x00")
+		summary, exists := groups[key]
+		if !exists {
+			summary = &DailySummary{
+				TenantID:    entry.TenantID,
+				SummaryDate: summaryDate,
+				RegionCode:  entry.RegionCode,
+				MerchantID:  entry.MerchantID,
+				Currency:    entry.Currency,
+				GeneratedAt: generatedAt,
+			}
+			groups[key] = summary
+		}
+
+		switch entry.EntryType {
+		case LedgerEntryPayment:
+			summary.TransactionCount++
+			switch entry.Status {
+			case TransactionStatusApproved:
+				summary.ApprovedCount++
+				summary.ApprovedVolume += entry.Amount
+			case TransactionStatusDenied:
+				summary.DeniedCount++
+			case TransactionStatusChallenged:
+				summary.ChallengedCount++
+			case TransactionStatusError:
+				summary.ErrorCount++
+			}
+		case LedgerEntryRefund:
+			summary.RefundCount++
+			summary.RefundVolume += entry.Amount
+		case LedgerEntryDispute:
+			summary.DisputeCount++
+			summary.DisputeVolume += entry.Amount
+		}
+	}
+
+	summaries := make([]*DailySummary, 0, len(groups))
+	for _, summary := range groups {
+		if summary.TransactionCount > 0 {
+			summary.ApprovalRate = roundSummaryValue(float64(summary.ApprovedCount)/float64(summary.TransactionCount), 4)
+		}
+		summary.ApprovedVolume = roundSummaryValue(summary.ApprovedVolume, 2)
+		summary.RefundVolume = roundSummaryValue(summary.RefundVolume, 2)
+		summary.DisputeVolume = roundSummaryValue(summary.DisputeVolume, 2)
+		summary.NetVolume = roundSummaryValue(summary.ApprovedVolume-summary.RefundVolume-summary.DisputeVolume, 2)
+		summaries = append(summaries, summary)
+	}
+
+	sortDailySummaries(summaries)
+	return summaries
+}
+
+// summaryTenants retorna os tenants distintos dos resumos, pela ordem em que aparecem
+func summaryTenants(summaries []*DailySummary) []string {
+	seen := make(map[string]bool)
+	var tenants []string
+	for _, summary := range summaries {
+		if !seen[summary.TenantID] {
+			seen[summary.TenantID] = true
+			tenants = append(tenants, summary.TenantID)
+		}
+	}
+	return tenants
+}
+
+// roundSummaryValue arredonda o valor às casas decimais indicadas
+func roundSummaryValue(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,164 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DailySummaryStore define a persistência do livro diário, dos resumos e das exportações
+type DailySummaryStore interface {
+	// AppendLedgerEntry grava um lançamento do livro diário
+	AppendLedgerEntry(ctx context.Context, entry *LedgerEntry) error
+
+	// ListLedgerEntries lista os lançamentos de todos os tenants no intervalo [from, to)
+	ListLedgerEntries(ctx context.Context, from, to time.Time) ([]*LedgerEntry, error)
+
+	// ReplaceSummaries substitui os resumos do dia e marca-o como fechado, tornando o fecho idempotente
+	ReplaceSummaries(ctx context.Context, summaryDate string, summaries []*DailySummary) error
+
+	// IsDayClosed indica se o fecho do dia já foi gravado
+	IsDayClosed(ctx context.Context, summaryDate string) (bool, error)
+
+	// ListSummaries lista os resumos do tenant que satisfazem o filtro, por data e comerciante
+	ListSummaries(ctx context.Context, tenantID string, filter DailySummaryFilter) ([]*DailySummary, error)
+
+	// SaveExport regista um ficheiro exportado
+	SaveExport(ctx context.Context, export *DailySummaryExport) error
+
+	// ListExports lista os ficheiros exportados do tenant para o dia
+	ListExports(ctx context.Context, tenantID, summaryDate string) ([]*DailySummaryExport, error)
+}
+
+// InMemoryDailySummaryStore armazena o livro diário, os resumos e as exportações em memória
+type InMemoryDailySummaryStore struct {
+	entries   []*LedgerEntry
+	summaries map[string][]*DailySummary // Por data do resumo
+	exports   []*DailySummaryExport
+	mutex     sync.RWMutex
+}
+
+// NewInMemoryDailySummaryStore cria um novo armazenamento em memória
+func NewInMemoryDailySummaryStore() *InMemoryDailySummaryStore {
+	return &InMemoryDailySummaryStore{summaries: make(map[string][]*DailySummary)}
+}
+
+// AppendLedgerEntry grava uma cópia do lançamento
+func (s *InMemoryDailySummaryStore) AppendLedgerEntry(ctx context.Context, entry *LedgerEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *entry
+	s.entries = append(s.entries, &stored)
+	return nil
+}
+
+// ListLedgerEntries lista cópias dos lançamentos no intervalo [from, to)
+func (s *InMemoryDailySummaryStore) ListLedgerEntries(ctx context.Context, from, to time.Time) ([]*LedgerEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var entries []*LedgerEntry
+	for _, entry := range s.entries {
+		if !entry.OccurredAt.Before(from) && entry.OccurredAt.Before(to) {
+			stored := *entry
+			entries = append(entries, &stored)
+		}
+	}
+	return entries, nil
+}
+
+// ReplaceSummaries substitui os resumos do dia
+func (s *InMemoryDailySummaryStore) ReplaceSummaries(ctx context.Context, summaryDate string, summaries []*DailySummary) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := make([]*DailySummary, 0, len(summaries))
+	for _, summary := range summaries {
+		copied := *summary
+		stored = append(stored, &copied)
+	}
+	s.summaries[summaryDate] = stored
+	return nil
+}
+
+// IsDayClosed indica se existem resumos gravados para o dia, mesmo que vazios
+func (s *InMemoryDailySummaryStore) IsDayClosed(ctx context.Context, summaryDate string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	_, closed := s.summaries[summaryDate]
+	return closed, nil
+}
+
+// ListSummaries lista cópias dos resumos do tenant que satisfazem o filtro
+func (s *InMemoryDailySummaryStore) ListSummaries(ctx context.Context, tenantID string, filter DailySummaryFilter) ([]*DailySummary, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var summaries []*DailySummary
+	for date, daySummaries := range s.summaries {
+		// Datas AAAA-MM-DD comparam-se lexicograficamente
+		if date < filter.From || date > filter.To {
+			continue
+		}
+		for _, summary := range daySummaries {
+			if summary.TenantID != tenantID ||
+				(filter.RegionCode != "" && summary.RegionCode != filter.RegionCode) ||
+				(filter.MerchantID != "" && summary.MerchantID != filter.MerchantID) {
+				continue
+			}
+			copied := *summary
+			summaries = append(summaries, &copied)
+		}
+	}
+
+	sortDailySummaries(summaries)
+	if filter.Limit > 0 && len(summaries) > filter.Limit {
+		summaries = summaries[:filter.Limit]
+	}
+	return summaries, nil
+}
+
+// SaveExport regista uma cópia da exportação
+func (s *InMemoryDailySummaryStore) SaveExport(ctx context.Context, export *DailySummaryExport) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *export
+	s.exports = append(s.exports, &stored)
+	return nil
+}
+
+// ListExports lista cópias das exportações do tenant para o dia
+func (s *InMemoryDailySummaryStore) ListExports(ctx context.Context, tenantID, summaryDate string) ([]*DailySummaryExport, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var exports []*DailySummaryExport
+	for _, export := range s.exports {
+		if export.TenantID == tenantID && export.SummaryDate == summaryDate {
+			stored := *export
+			exports = append(exports, &stored)
+		}
+	}
+	return exports, nil
+}
+
+// sortDailySummaries ordena os resumos por data, mercado, comerciante e moeda
+func sortDailySummaries(summaries []*DailySummary) {
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.SummaryDate != b.SummaryDate {
+			return a.SummaryDate < b.SummaryDate
+		}
+		if a.RegionCode != b.RegionCode {
+			return a.RegionCode < b.RegionCode
+		}
+		if a.MerchantID != b.MerchantID {
+			return a.MerchantID < b.MerchantID
+		}
+		return a.Currency < b.Currency
+	})
+}
@@ -0,0 +1,86 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// DeviceHandler expõe a API HTTP de gestão de dispositivos para as equipas de suporte
+type DeviceHandler struct {
+	service *DeviceTrustService
+}
+
+// NewDeviceHandler cria uma nova instância do DeviceHandler
+func NewDeviceHandler(service *DeviceTrustService) *DeviceHandler {
+	return &DeviceHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *DeviceHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/users/{userId}/devices", h.ListDevices).Methods(http.MethodGet)
+	router.HandleFunc("/users/{userId}/devices/{deviceId}/label", h.LabelDevice).Methods(http.MethodPut)
+	router.HandleFunc("/users/{userId}/devices/{deviceId}/revoke-trust", h.RevokeTrust).Methods(http.MethodPost)
+}
+
+// ListDevices trata a listagem dos dispositivos conhecidos de um usuário
+func (h *DeviceHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.service.ListDevices(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["userId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, devices)
+}
+
+// LabelDevice trata a atribuição de um nome a um dispositivo
+func (h *DeviceHandler) LabelDevice(w http.ResponseWriter, r *http.Request) {
+	var req DeviceLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	vars := mux.Vars(r)
+	device, err := h.service.LabelDevice(r.Context(), r.Header.Get("X-Tenant-ID"), vars["userId"], vars["deviceId"], req.Label)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, device)
+}
+
+// RevokeTrust trata a revogação da confiança num dispositivo
+func (h *DeviceHandler) RevokeTrust(w http.ResponseWriter, r *http.Request) {
+	var req DeviceTrustRevocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	if req.ActorID == "" {
+		req.ActorID = r.Header.Get("X-User-ID")
+	}
+
+	vars := mux.Vars(r)
+	device, err := h.service.RevokeTrust(r.Context(), r.Header.Get("X-Tenant-ID"), vars["userId"], vars["deviceId"], &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, device)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *DeviceHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrDeviceNotFound):
+		respondWithError(w, http.StatusNotFound, "device_not_found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "device_error", err.Error())
+	}
+}
@@ -0,0 +1,88 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Níveis de confiança de dispositivo
+	DeviceTrustLevelHigh    = "high"
+	DeviceTrustLevelMedium  = "medium"
+	DeviceTrustLevelLow     = "low"
+	DeviceTrustLevelNew     = "new"
+	DeviceTrustLevelRevoked = "revoked"
+
+	// Similaridade mínima para associar uma impressão digital a um dispositivo conhecido
+	DefaultDeviceSimilarityThreshold = 0.8
+
+	// Pagamentos aprovados necessários para um dispositivo passar a confiável
+	DefaultDeviceTrustAfterPayments = 3
+
+	// Pontuação mínima para um dispositivo ser considerado de confiança alta
+	DefaultDeviceHighTrustScore = 80
+)
+
+// Erros do subsistema de dispositivos
+var (
+	ErrDeviceNotFound           = errors.New("dispositivo não encontrado")
+	ErrDeviceFingerprintMissing = errors.New("impressão digital do dispositivo não informada")
+)
+
+// DeviceProfile representa um dispositivo conhecido de um usuário
+type DeviceProfile struct {
+	DeviceID           string                 `json:"device_id"`
+	TenantID           string                 `json:"tenant_id"`
+	UserID             string                 `json:"user_id"`
+	Fingerprint        string                 `json:"fingerprint"`
+	Label              string                 `json:"label,omitempty"`
+	DeviceType         string                 `json:"device_type"`
+	DeviceModel        string                 `json:"device_model,omitempty"`
+	OperatingSystem    string                 `json:"operating_system,omitempty"`
+	OSVersion          string                 `json:"os_version,omitempty"`
+	Browser            string                 `json:"browser,omitempty"`
+	BrowserVersion     string                 `json:"browser_version,omitempty"`
+	UserAgent          string                 `json:"user_agent,omitempty"`
+	Attributes         map[string]interface{} `json:"attributes,omitempty"`
+	LastIPAddress      string                 `json:"last_ip_address,omitempty"`
+	LastCountry        string                 `json:"last_country,omitempty"`
+	Trusted            bool                   `json:"trusted"`
+	TrustRevokedAt     *time.Time             `json:"trust_revoked_at,omitempty"`
+	TrustRevokedBy     string                 `json:"trust_revoked_by,omitempty"`
+	RevocationReason   string                 `json:"revocation_reason,omitempty"`
+	SeenCount          int64                  `json:"seen_count"`
+	SuccessfulPayments int64                  `json:"successful_payments"`
+	FirstSeen          time.Time              `json:"first_seen"`
+	LastSeen           time.Time              `json:"last_seen"`
+}
+
+// TrustRevoked indica se a confiança no dispositivo foi revogada pelo suporte
+func (d *DeviceProfile) TrustRevoked() bool {
+	return d.TrustRevokedAt != nil
+}
+
+// DeviceAssessment contém o resultado da avaliação do dispositivo de um pagamento
+type DeviceAssessment struct {
+	DeviceID      string   `json:"device_id,omitempty"`
+	KnownDevice   bool     `json:"known_device"`
+	Similarity    float64  `json:"similarity"`
+	TrustScore    int      `json:"trust_score"`
+	TrustLevel    string   `json:"trust_level"`
+	Trusted       bool     `json:"trusted"`
+	CountryChange bool     `json:"country_change"`
+	Factors       []string `json:"factors,omitempty"`
+
+	// Indica se o dispositivo pode sustentar uma isenção de autenticação forte
+	SCAExemptionEligible bool `json:"sca_exemption_eligible"`
+}
+
+// DeviceLabelRequest representa a atribuição de um nome a um dispositivo pelo suporte
+type DeviceLabelRequest struct {
+	Label string `json:"label"`
+}
+
+// DeviceTrustRevocationRequest representa a revogação da confiança num dispositivo
+type DeviceTrustRevocationRequest struct {
+	ActorID string `json:"actor_id"`
+	Reason  string `json:"reason"`
+}
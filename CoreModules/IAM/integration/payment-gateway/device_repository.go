@@ -0,0 +1,228 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DeviceRepository define a interface de persistência de perfis de dispositivo
+type DeviceRepository interface {
+	// ListDevices lista os dispositivos conhecidos de um usuário
+	ListDevices(ctx context.Context, tenantID, userID string) ([]*DeviceProfile, error)
+
+	// GetDevice recupera um dispositivo pelo ID
+	GetDevice(ctx context.Context, tenantID, deviceID string) (*DeviceProfile, error)
+
+	// SaveDevice cria ou atualiza um perfil de dispositivo
+	SaveDevice(ctx context.Context, device *DeviceProfile) error
+}
+
+// PostgresDeviceRepository implementa DeviceRepository para PostgreSQL
+type PostgresDeviceRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresDeviceRepository cria uma nova instância de PostgresDeviceRepository
+func NewPostgresDeviceRepository(db *sqlx.DB) *PostgresDeviceRepository {
+	return &PostgresDeviceRepository{db: db}
+}
+
+// dbDeviceProfile é a representação do perfil de dispositivo na base de dados
+type dbDeviceProfile struct {
+	DeviceID           string         `db:"device_id"`
+	TenantID           string         `db:"tenant_id"`
+	UserID             string         `db:"user_id"`
+	Fingerprint        string         `db:"fingerprint"`
+	Label              sql.NullString `db:"label"`
+	DeviceType         string         `db:"device_type"`
+	DeviceModel        string         `db:"device_model"`
+	OperatingSystem    string         `db:"operating_system"`
+	OSVersion          string         `db:"os_version"`
+	Browser            string         `db:"browser"`
+	BrowserVersion     string         `db:"browser_version"`
+	UserAgent          string         `db:"user_agent"`
+	Attributes         []byte         `db:"attributes"`
+	LastIPAddress      string         `db:"last_ip_address"`
+	LastCountry        string         `db:"last_country"`
+	Trusted            bool           `db:"trusted"`
+	TrustRevokedAt     sql.NullTime   `db:"trust_revoked_at"`
+	TrustRevokedBy     sql.NullString `db:"trust_revoked_by"`
+	RevocationReason   sql.NullString `db:"revocation_reason"`
+	SeenCount          int64          `db:"seen_count"`
+	SuccessfulPayments int64          `db:"successful_payments"`
+	FirstSeen          time.Time      `db:"first_seen"`
+	LastSeen           time.Time      `db:"last_seen"`
+}
+
+// toDeviceProfile converte um dbDeviceProfile para DeviceProfile
+func (dd *dbDeviceProfile) toDeviceProfile() (*DeviceProfile, error) {
+	device := &DeviceProfile{
+		DeviceID:           dd.DeviceID,
+		TenantID:           dd.TenantID,
+		UserID:             dd.UserID,
+		Fingerprint:        dd.Fingerprint,
+		Label:              dd.Label.String,
+		DeviceType:         dd.DeviceType,
+		DeviceModel:        dd.DeviceModel,
+		OperatingSystem:    dd.OperatingSystem,
+		OSVersion:          dd.OSVersion,
+		Browser:            dd.Browser,
+		BrowserVersion:     dd.BrowserVersion,
+		UserAgent:          dd.UserAgent,
+		LastIPAddress:      dd.LastIPAddress,
+		LastCountry:        dd.LastCountry,
+		Trusted:            dd.Trusted,
+		TrustRevokedBy:     dd.TrustRevokedBy.String,
+		RevocationReason:   dd.RevocationReason.String,
+		SeenCount:          dd.SeenCount,
+		SuccessfulPayments: dd.SuccessfulPayments,
+		FirstSeen:          dd.FirstSeen,
+		LastSeen:           dd.LastSeen,
+	}
+
+	if dd.TrustRevokedAt.Valid {
+		revokedAt := dd.TrustRevokedAt.Time
+		device.TrustRevokedAt = &revokedAt
+	}
+
+	if len(dd.Attributes) > 0 {
+		if err := json.Unmarshal(dd.Attributes, &device.Attributes); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar atributos do dispositivo: %w", err)
+		}
+	}
+
+	return device, nil
+}
+
+// fromDeviceProfile converte um DeviceProfile para dbDeviceProfile
+func fromDeviceProfile(d *DeviceProfile) (*dbDeviceProfile, error) {
+	attributes, err := json.Marshal(d.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao codificar atributos do dispositivo: %w", err)
+	}
+
+	dd := &dbDeviceProfile{
+		DeviceID:           d.DeviceID,
+		TenantID:           d.TenantID,
+		UserID:             d.UserID,
+		Fingerprint:        d.Fingerprint,
+		DeviceType:         d.DeviceType,
+		DeviceModel:        d.DeviceModel,
+		OperatingSystem:    d.OperatingSystem,
+		OSVersion:          d.OSVersion,
+		Browser:            d.Browser,
+		BrowserVersion:     d.BrowserVersion,
+		UserAgent:          d.UserAgent,
+		Attributes:         attributes,
+		LastIPAddress:      d.LastIPAddress,
+		LastCountry:        d.LastCountry,
+		Trusted:            d.Trusted,
+		SeenCount:          d.SeenCount,
+		SuccessfulPayments: d.SuccessfulPayments,
+		FirstSeen:          d.FirstSeen,
+		LastSeen:           d.LastSeen,
+	}
+
+	if d.Label != "" {
+		dd.Label = sql.NullString{String: d.Label, Valid: true}
+	}
+
+	if d.TrustRevokedAt != nil {
+		dd.TrustRevokedAt = sql.NullTime{Time: *d.TrustRevokedAt, Valid: true}
+		dd.TrustRevokedBy = sql.NullString{String: d.TrustRevokedBy, Valid: d.TrustRevokedBy != ""}
+		dd.RevocationReason = sql.NullString{String: d.RevocationReason, Valid: d.RevocationReason != ""}
+	}
+
+	return dd, nil
+}
+
+// ListDevices lista os dispositivos conhecidos de um usuário
+func (r *PostgresDeviceRepository) ListDevices(ctx context.Context, tenantID, userID string) ([]*DeviceProfile, error) {
+	var rows []dbDeviceProfile
+	query := `SELECT * FROM payment_gateway.device_profiles WHERE tenant_id = $1 AND user_id = $2 ORDER BY last_seen DESC`
+	if err := r.db.SelectContext(ctx, &rows, query, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("falha ao listar dispositivos: %w", err)
+	}
+
+	devices := make([]*DeviceProfile, 0, len(rows))
+	for i := range rows {
+		device, err := rows[i].toDeviceProfile()
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// GetDevice recupera um dispositivo pelo ID
+func (r *PostgresDeviceRepository) GetDevice(ctx context.Context, tenantID, deviceID string) (*DeviceProfile, error) {
+	var dd dbDeviceProfile
+	query := `SELECT * FROM payment_gateway.device_profiles WHERE tenant_id = $1 AND device_id = $2`
+	if err := r.db.GetContext(ctx, &dd, query, tenantID, deviceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar dispositivo: %w", err)
+	}
+
+	return dd.toDeviceProfile()
+}
+
+// SaveDevice cria ou atualiza um perfil de dispositivo
+func (r *PostgresDeviceRepository) SaveDevice(ctx context.Context, device *DeviceProfile) error {
+	dd, err := fromDeviceProfile(device)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.device_profiles (
+			device_id, tenant_id, user_id, fingerprint, label,
+			device_type, device_model, operating_system, os_version,
+			browser, browser_version, user_agent, attributes,
+			last_ip_address, last_country, trusted, trust_revoked_at, trust_revoked_by,
+			revocation_reason, seen_count, successful_payments, first_seen, last_seen
+		) VALUES (
+			:device_id, :tenant_id, :user_id, :fingerprint, :label,
+			:device_type, :device_model, :operating_system, :os_version,
+			:browser, :browser_version, :user_agent, :attributes,
+			:last_ip_address, :last_country, :trusted, :trust_revoked_at, :trust_revoked_by,
+			:revocation_reason, :seen_count, :successful_payments, :first_seen, :last_seen
+		)
+		ON CONFLICT (device_id) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			label = EXCLUDED.label,
+			device_type = EXCLUDED.device_type,
+			device_model = EXCLUDED.device_model,
+			operating_system = EXCLUDED.operating_system,
+			os_version = EXCLUDED.os_version,
+			browser = EXCLUDED.browser,
+			browser_version = EXCLUDED.browser_version,
+			user_agent = EXCLUDED.user_agent,
+			attributes = EXCLUDED.attributes,
+			last_ip_address = EXCLUDED.last_ip_address,
+			last_country = EXCLUDED.last_country,
+			trusted = EXCLUDED.trusted,
+			trust_revoked_at = EXCLUDED.trust_revoked_at,
+			trust_revoked_by = EXCLUDED.trust_revoked_by,
+			revocation_reason = EXCLUDED.revocation_reason,
+			seen_count = EXCLUDED.seen_count,
+			successful_payments = EXCLUDED.successful_payments,
+			last_seen = EXCLUDED.last_seen
+		WHERE payment_gateway.device_profiles.tenant_id = EXCLUDED.tenant_id
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, dd); err != nil {
+		return fmt.Errorf("falha ao salvar dispositivo: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package paymentgateway
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deviceProfilesMigration cria a tabela lida pelo PostgresDeviceRepository
+const deviceProfilesMigration = "../../database/migrations/V31__payment_gateway_device_profiles.sql"
+
+func TestDeviceProfilesMigrationMatchesRepositoryColumns(t *testing.T) {
+	sqlData, err := os.ReadFile(deviceProfilesMigration)
+	require.NoError(t, err)
+
+	table := regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS payment_gateway\.device_profiles \((.*?)\n\);`).FindSubmatch(sqlData)
+	require.NotNil(t, table, "a migração deve criar payment_gateway.device_profiles")
+
+	var columns []string
+	for _, line := range strings.Split(string(table[1]), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "CONSTRAINT") {
+			continue
+		}
+		columns = append(columns, strings.Fields(line)[0])
+	}
+
+	// O repositório lê com SELECT *: cada coluna precisa de um campo em dbDeviceProfile e vice-versa
+	var fields []string
+	structType := reflect.TypeOf(dbDeviceProfile{})
+	for i := 0; i < structType.NumField(); i++ {
+		fields = append(fields, structType.Field(i).Tag.Get("db"))
+	}
+
+	sort.Strings(columns)
+	sort.Strings(fields)
+	assert.Equal(t, fields, columns)
+	assert.Contains(t, string(table[1]), "device_id VARCHAR(255) PRIMARY KEY", "o upsert usa ON CONFLICT (device_id)")
+}
+
+func TestDeviceProfileDatabaseRoundTrip(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(time.Hour)
+	device := &DeviceProfile{
+		DeviceID:           "dev-1",
+		TenantID:           "tenant-1",
+		UserID:             "user-1",
+		Fingerprint:        "fp-1",
+		Label:              "Telemóvel pessoal",
+		DeviceType:         "mobile",
+		OperatingSystem:    "Android",
+		Attributes:         map[string]interface{}{"screen": "1080x2400"},
+		LastCountry:        "AO",
+		TrustRevokedAt:     &revokedAt,
+		TrustRevokedBy:     "support-1",
+		RevocationReason:   "dispositivo roubado",
+		SeenCount:          4,
+		SuccessfulPayments: 2,
+		FirstSeen:          now,
+		LastSeen:           now,
+	}
+
+	row, err := fromDeviceProfile(device)
+	require.NoError(t, err)
+	assert.True(t, row.Label.Valid)
+	assert.True(t, row.TrustRevokedAt.Valid)
+
+	restored, err := row.toDeviceProfile()
+	require.NoError(t, err)
+	assert.Equal(t, device, restored)
+
+	t.Run("campos opcionais vazios ficam a NULL", func(t *testing.T) {
+		row, err := fromDeviceProfile(&DeviceProfile{DeviceID: "dev-2", Fingerprint: "fp-2", FirstSeen: now, LastSeen: now})
+		require.NoError(t, err)
+		assert.False(t, row.Label.Valid)
+		assert.False(t, row.TrustRevokedAt.Valid)
+		assert.False(t, row.TrustRevokedBy.Valid)
+
+		restored, err := row.toDeviceProfile()
+		require.NoError(t, err)
+		assert.Nil(t, restored.TrustRevokedAt)
+		assert.False(t, restored.TrustRevoked())
+	})
+}
@@ -0,0 +1,377 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// DeviceTrustConfig contém configurações da avaliação de confiança de dispositivos
+type DeviceTrustConfig struct {
+	// Similaridade mínima (0-1) para reconhecer um dispositivo cuja impressão digital mudou
+	SimilarityThreshold float64 `json:"similarity_threshold"`
+
+	// Pagamentos aprovados a partir dos quais o dispositivo passa a confiável
+	TrustAfterSuccessfulPayments int64 `json:"trust_after_successful_payments"`
+
+	// Pontuação a partir da qual a confiança no dispositivo é alta
+	HighTrustScore int `json:"high_trust_score"`
+
+	// Valor máximo de um pagamento isentável de autenticação forte num dispositivo
+	// confiável; zero desativa a isenção
+	SCAExemptionMaxAmount float64 `json:"sca_exemption_max_amount"`
+}
+
+// DeviceTrustService gerencia perfis de dispositivo e calcula a sua pontuação de confiança
+type DeviceTrustService struct {
+	config          DeviceTrustConfig
+	repository      DeviceRepository
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewDeviceTrustService cria uma nova instância do serviço de confiança de dispositivos
+func NewDeviceTrustService(config DeviceTrustConfig, repository DeviceRepository) (*DeviceTrustService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-device-trust",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.SimilarityThreshold == 0 {
+		config.SimilarityThreshold = DefaultDeviceSimilarityThreshold
+	}
+
+	if config.TrustAfterSuccessfulPayments == 0 {
+		config.TrustAfterSuccessfulPayments = DefaultDeviceTrustAfterPayments
+	}
+
+	if config.HighTrustScore == 0 {
+		config.HighTrustScore = DefaultDeviceHighTrustScore
+	}
+
+	return &DeviceTrustService{
+		config:          config,
+		repository:      repository,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}, nil
+}
+
+// AssessDevice associa o dispositivo do pagamento a um perfil conhecido do usuário, ou
+// regista um novo perfil, e calcula a pontuação de confiança
+func (s *DeviceTrustService) AssessDevice(ctx context.Context, req *PaymentRequest) (*DeviceAssessment, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "DeviceTrustService.AssessDevice")
+	defer span.End()
+
+	info := req.DeviceInfo
+	if info.DeviceFingerprint == "" {
+		return &DeviceAssessment{
+			TrustLevel: DeviceTrustLevelNew,
+			Factors:    []string{"fingerprint_missing"},
+		}, nil
+	}
+
+	devices, err := s.repository.ListDevices(ctx, req.TenantID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *DeviceProfile
+	var similarity float64
+	for _, device := range devices {
+		if sim := deviceSimilarity(device, info); sim > similarity {
+			best, similarity = device, sim
+		}
+	}
+
+	now := s.now()
+	var assessment *DeviceAssessment
+
+	if best == nil || similarity < s.config.SimilarityThreshold {
+		device := &DeviceProfile{
+			DeviceID:  fmt.Sprintf("dev-%s", uuid.New().String()),
+			TenantID:  req.TenantID,
+			UserID:    req.UserID,
+			FirstSeen: now,
+		}
+		updateDeviceProfile(device, info, now)
+
+		if err := s.repository.SaveDevice(ctx, device); err != nil {
+			return nil, err
+		}
+
+		assessment = &DeviceAssessment{
+			DeviceID:   device.DeviceID,
+			TrustLevel: DeviceTrustLevelNew,
+			Factors:    []string{"new_device"},
+		}
+		if len(devices) > 0 {
+			assessment.Factors = append(assessment.Factors, "user_has_other_devices")
+		}
+
+		s.logger.InfoWithContext(ctx, "Novo dispositivo registado para o usuário",
+			"device_id", device.DeviceID,
+			"user_id", req.UserID,
+			"tenant_id", req.TenantID)
+	} else {
+		countryChange := best.LastCountry != "" && info.LocationInfo.Country != "" &&
+			!strings.EqualFold(best.LastCountry, info.LocationInfo.Country)
+
+		// A pontuação reflete o histórico anterior a esta utilização
+		assessment = s.scoreDevice(best, similarity, countryChange, now)
+		assessment.SCAExemptionEligible = assessment.SCAExemptionEligible &&
+			s.config.SCAExemptionMaxAmount > 0 && req.Amount <= s.config.SCAExemptionMaxAmount
+
+		updateDeviceProfile(best, info, now)
+		if err := s.repository.SaveDevice(ctx, best); err != nil {
+			return nil, err
+		}
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_device_assessments_total", map[string]string{
+		"tenant_id":   req.TenantID,
+		"trust_level": assessment.TrustLevel,
+	})
+
+	return assessment, nil
+}
+
+// RecordPaymentOutcome atualiza o histórico do dispositivo após o processamento do pagamento
+// e promove o dispositivo a confiável após o número configurado de pagamentos aprovados
+func (s *DeviceTrustService) RecordPaymentOutcome(ctx context.Context, req *PaymentRequest, resp *PaymentResponse, assessment *DeviceAssessment) {
+	if assessment == nil || assessment.DeviceID == "" || resp.Status != TransactionStatusApproved {
+		return
+	}
+
+	ctx, span := s.tracer.StartSpan(ctx, "DeviceTrustService.RecordPaymentOutcome")
+	defer span.End()
+
+	device, err := s.repository.GetDevice(ctx, req.TenantID, assessment.DeviceID)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao recuperar dispositivo do pagamento",
+			"device_id", assessment.DeviceID,
+			"error", err.Error())
+		return
+	}
+
+	device.SuccessfulPayments++
+
+	// Uma revogação pelo suporte impede a promoção automática
+	if !device.Trusted && !device.TrustRevoked() && device.SuccessfulPayments >= s.config.TrustAfterSuccessfulPayments {
+		device.Trusted = true
+		s.logger.InfoWithContext(ctx, "Dispositivo promovido a confiável",
+			"device_id", device.DeviceID,
+			"user_id", device.UserID,
+			"successful_payments", device.SuccessfulPayments)
+	}
+
+	if err := s.repository.SaveDevice(ctx, device); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao salvar histórico do dispositivo",
+			"device_id", device.DeviceID,
+			"error", err.Error())
+	}
+}
+
+// ListDevices lista os dispositivos conhecidos de um usuário
+func (s *DeviceTrustService) ListDevices(ctx context.Context, tenantID, userID string) ([]*DeviceProfile, error) {
+	return s.repository.ListDevices(ctx, tenantID, userID)
+}
+
+// LabelDevice atribui um nome ao dispositivo para facilitar a identificação pelo suporte
+func (s *DeviceTrustService) LabelDevice(ctx context.Context, tenantID, userID, deviceID, label string) (*DeviceProfile, error) {
+	device, err := s.userDevice(ctx, tenantID, userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Label = strings.TrimSpace(label)
+	if err := s.repository.SaveDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// RevokeTrust retira a confiança num dispositivo, por exemplo após perda ou roubo
+func (s *DeviceTrustService) RevokeTrust(ctx context.Context, tenantID, userID, deviceID string, req *DeviceTrustRevocationRequest) (*DeviceProfile, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "DeviceTrustService.RevokeTrust")
+	defer span.End()
+
+	device, err := s.userDevice(ctx, tenantID, userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	device.Trusted = false
+	device.TrustRevokedAt = &now
+	device.TrustRevokedBy = req.ActorID
+	device.RevocationReason = req.Reason
+
+	if err := s.repository.SaveDevice(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_device_trust_revocations_total", map[string]string{
+		"tenant_id": tenantID,
+	})
+
+	s.logger.InfoWithContext(ctx, "Confiança no dispositivo revogada",
+		"device_id", deviceID,
+		"user_id", userID,
+		"actor_id", req.ActorID,
+		"reason", req.Reason)
+
+	return device, nil
+}
+
+// userDevice recupera um dispositivo garantindo que pertence ao usuário indicado
+func (s *DeviceTrustService) userDevice(ctx context.Context, tenantID, userID, deviceID string) (*DeviceProfile, error) {
+	device, err := s.repository.GetDevice(ctx, tenantID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.UserID != userID {
+		return nil, ErrDeviceNotFound
+	}
+
+	return device, nil
+}
+
+// scoreDevice calcula a pontuação de confiança (0-100) de um dispositivo conhecido
+func (s *DeviceTrustService) scoreDevice(device *DeviceProfile, similarity float64, countryChange bool, now time.Time) *DeviceAssessment {
+	assessment := &DeviceAssessment{
+		DeviceID:      device.DeviceID,
+		KnownDevice:   true,
+		Similarity:    similarity,
+		Trusted:       device.Trusted,
+		CountryChange: countryChange,
+	}
+
+	if device.TrustRevoked() {
+		assessment.TrustLevel = DeviceTrustLevelRevoked
+		assessment.Factors = []string{"trust_revoked"}
+		return assessment
+	}
+
+	// Correspondência da impressão digital, antiguidade, histórico de pagamentos e confiança
+	score := similarity * 40
+	score += math.Min(now.Sub(device.FirstSeen).Hours()/24/30, 1) * 20
+	score += math.Min(float64(device.SuccessfulPayments)/10, 1) * 20
+	if device.Trusted {
+		score += 20
+		assessment.Factors = append(assessment.Factors, "trusted_device")
+	}
+
+	if similarity < 1 {
+		assessment.Factors = append(assessment.Factors, "fingerprint_drift")
+	}
+
+	if countryChange {
+		score -= 20
+		assessment.Factors = append(assessment.Factors, "country_change")
+	}
+
+	assessment.TrustScore = int(math.Round(math.Max(0, math.Min(score, 100))))
+
+	switch {
+	case assessment.TrustScore >= s.config.HighTrustScore:
+		assessment.TrustLevel = DeviceTrustLevelHigh
+	case assessment.TrustScore >= 50:
+		assessment.TrustLevel = DeviceTrustLevelMedium
+	default:
+		assessment.TrustLevel = DeviceTrustLevelLow
+	}
+
+	assessment.SCAExemptionEligible = device.Trusted && !countryChange && assessment.TrustLevel == DeviceTrustLevelHigh
+
+	return assessment
+}
+
+// deviceSimilarity compara as características de um perfil com o dispositivo do pagamento
+// A correspondência exata da impressão digital vale 1; caso contrário, as características são
+// ponderadas, com menor peso para versões que mudam com atualizações
+func deviceSimilarity(device *DeviceProfile, info DeviceInfo) float64 {
+	if device.Fingerprint == info.DeviceFingerprint {
+		return 1
+	}
+
+	// Tipo de dispositivo e sistema operativo diferentes indicam outro dispositivo
+	if !strings.EqualFold(device.DeviceType, info.DeviceType) ||
+		!strings.EqualFold(device.OperatingSystem, info.OperatingSystem) {
+		return 0
+	}
+
+	var score, weight float64
+	compare := func(w float64, a, b string) {
+		if a == "" && b == "" {
+			return
+		}
+		weight += w
+		if strings.EqualFold(a, b) {
+			score += w
+		}
+	}
+
+	compare(2, device.DeviceType, info.DeviceType)
+	compare(2, device.OperatingSystem, info.OperatingSystem)
+	compare(2, device.DeviceModel, info.DeviceModel)
+	compare(2, device.Browser, info.Browser)
+	compare(1, device.OSVersion, info.OSVersion)
+	compare(1, device.BrowserVersion, info.BrowserVersion)
+	compare(1, device.UserAgent, info.UserAgent)
+
+	// Atributos presentes apenas num dos lados contam no peso sem pontuar
+	for key, value := range device.Attributes {
+		other, ok := info.DeviceAttributes[key]
+		if !ok {
+			weight++
+			continue
+		}
+		compare(1, fmt.Sprint(value), fmt.Sprint(other))
+	}
+	for key := range info.DeviceAttributes {
+		if _, ok := device.Attributes[key]; !ok {
+			weight++
+		}
+	}
+
+	if weight == 0 {
+		return 0
+	}
+	return score / weight
+}
+
+// updateDeviceProfile atualiza o perfil com as características observadas no pagamento
+func updateDeviceProfile(device *DeviceProfile, info DeviceInfo, now time.Time) {
+	device.Fingerprint = info.DeviceFingerprint
+	device.DeviceType = info.DeviceType
+	device.DeviceModel = info.DeviceModel
+	device.OperatingSystem = info.OperatingSystem
+	device.OSVersion = info.OSVersion
+	device.Browser = info.Browser
+	device.BrowserVersion = info.BrowserVersion
+	device.UserAgent = info.UserAgent
+	device.Attributes = info.DeviceAttributes
+	device.LastIPAddress = info.IPAddress
+	if info.LocationInfo.Country != "" {
+		device.LastCountry = info.LocationInfo.Country
+	}
+	device.SeenCount++
+	device.LastSeen = now
+}
@@ -0,0 +1,236 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeviceRepository implementa DeviceRepository em memória
+type fakeDeviceRepository struct {
+	mu      sync.Mutex
+	devices map[string]DeviceProfile
+}
+
+func (r *fakeDeviceRepository) ListDevices(ctx context.Context, tenantID, userID string) ([]*DeviceProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var devices []*DeviceProfile
+	for _, device := range r.devices {
+		if device.TenantID == tenantID && device.UserID == userID {
+			copied := device
+			devices = append(devices, &copied)
+		}
+	}
+	return devices, nil
+}
+
+func (r *fakeDeviceRepository) GetDevice(ctx context.Context, tenantID, deviceID string) (*DeviceProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	device, ok := r.devices[deviceID]
+	if !ok || device.TenantID != tenantID {
+		return nil, ErrDeviceNotFound
+	}
+	return &device, nil
+}
+
+func (r *fakeDeviceRepository) SaveDevice(ctx context.Context, device *DeviceProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices[device.DeviceID] = *device
+	return nil
+}
+
+// newTestDeviceTrustService cria o serviço com o relógio fixo em now e isenção até 100
+func newTestDeviceTrustService(t *testing.T, now time.Time) (*DeviceTrustService, *fakeDeviceRepository) {
+	t.Helper()
+	repository := &fakeDeviceRepository{devices: make(map[string]DeviceProfile)}
+	service, err := NewDeviceTrustService(DeviceTrustConfig{SCAExemptionMaxAmount: 100}, repository)
+	require.NoError(t, err)
+	service.now = func() time.Time { return now }
+	return service, repository
+}
+
+// devicePaymentRequest cria um pagamento feito a partir de um telemóvel Android em Angola
+func devicePaymentRequest(fingerprint string) *PaymentRequest {
+	return &PaymentRequest{
+		TenantID: "tenant-1",
+		UserID:   "user-1",
+		Amount:   50,
+		DeviceInfo: DeviceInfo{
+			DeviceFingerprint: fingerprint,
+			DeviceType:        "mobile",
+			DeviceModel:       "Pixel 8",
+			OperatingSystem:   "Android",
+			OSVersion:         "14",
+			Browser:           "Chrome",
+			BrowserVersion:    "129",
+			UserAgent:         "Mozilla/5.0 (Linux; Android 14)",
+			IPAddress:         "102.0.0.10",
+			LocationInfo:      LocationInfo{Country: "AO"},
+		},
+	}
+}
+
+func TestAssessDeviceRegistersNewDevice(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service, repository := newTestDeviceTrustService(t, now)
+
+	assessment, err := service.AssessDevice(context.Background(), devicePaymentRequest("fp-1"))
+	require.NoError(t, err)
+	assert.Equal(t, DeviceTrustLevelNew, assessment.TrustLevel)
+	assert.False(t, assessment.KnownDevice)
+	assert.Equal(t, []string{"new_device"}, assessment.Factors)
+
+	stored, err := repository.GetDevice(context.Background(), "tenant-1", assessment.DeviceID)
+	require.NoError(t, err)
+	assert.Equal(t, "fp-1", stored.Fingerprint)
+	assert.Equal(t, int64(1), stored.SeenCount)
+	assert.Equal(t, now, stored.FirstSeen)
+
+	// Outro sistema operativo é outro dispositivo, mesmo para o mesmo usuário
+	other := devicePaymentRequest("fp-2")
+	other.DeviceInfo.OperatingSystem = "iOS"
+	assessment, err = service.AssessDevice(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, DeviceTrustLevelNew, assessment.TrustLevel)
+	assert.Contains(t, assessment.Factors, "user_has_other_devices")
+	assert.Len(t, repository.devices, 2)
+
+	t.Run("sem impressão digital não regista dispositivo", func(t *testing.T) {
+		assessment, err := service.AssessDevice(context.Background(), devicePaymentRequest(""))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fingerprint_missing"}, assessment.Factors)
+		assert.Len(t, repository.devices, 2)
+	})
+}
+
+func TestAssessDeviceRecognisesFingerprintDrift(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service, repository := newTestDeviceTrustService(t, now)
+
+	first, err := service.AssessDevice(context.Background(), devicePaymentRequest("fp-1"))
+	require.NoError(t, err)
+
+	// Uma atualização do sistema muda a impressão digital e a versão, mas não o dispositivo
+	updated := devicePaymentRequest("fp-1-updated")
+	updated.DeviceInfo.OSVersion = "15"
+	assessment, err := service.AssessDevice(context.Background(), updated)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.DeviceID, assessment.DeviceID)
+	assert.True(t, assessment.KnownDevice)
+	assert.InDelta(t, 10.0/11.0, assessment.Similarity, 0.001)
+	assert.Contains(t, assessment.Factors, "fingerprint_drift")
+
+	stored, err := repository.GetDevice(context.Background(), "tenant-1", first.DeviceID)
+	require.NoError(t, err)
+	assert.Equal(t, "fp-1-updated", stored.Fingerprint)
+	assert.Equal(t, int64(2), stored.SeenCount)
+}
+
+func TestRecordPaymentOutcomePromotesTrustedDevice(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service, repository := newTestDeviceTrustService(t, now)
+	req := devicePaymentRequest("fp-1")
+
+	assessment, err := service.AssessDevice(context.Background(), req)
+	require.NoError(t, err)
+
+	approved := &PaymentResponse{Status: TransactionStatusApproved}
+	denied := &PaymentResponse{Status: TransactionStatusDenied}
+
+	service.RecordPaymentOutcome(context.Background(), req, denied, assessment)
+	for i := int64(1); i < DefaultDeviceTrustAfterPayments; i++ {
+		service.RecordPaymentOutcome(context.Background(), req, approved, assessment)
+	}
+	stored, _ := repository.GetDevice(context.Background(), "tenant-1", assessment.DeviceID)
+	assert.False(t, stored.Trusted, "pagamentos recusados não contam para a promoção")
+
+	service.RecordPaymentOutcome(context.Background(), req, approved, assessment)
+	stored, _ = repository.GetDevice(context.Background(), "tenant-1", assessment.DeviceID)
+	assert.True(t, stored.Trusted)
+	assert.Equal(t, int64(DefaultDeviceTrustAfterPayments), stored.SuccessfulPayments)
+}
+
+func TestRevokedDeviceIsNotPromotedOrExempted(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service, repository := newTestDeviceTrustService(t, now)
+	req := devicePaymentRequest("fp-1")
+
+	assessment, err := service.AssessDevice(context.Background(), req)
+	require.NoError(t, err)
+
+	_, err = service.RevokeTrust(context.Background(), "tenant-1", "user-1", assessment.DeviceID,
+		&DeviceTrustRevocationRequest{ActorID: "support-1", Reason: "dispositivo roubado"})
+	require.NoError(t, err)
+
+	for i := 0; i < DefaultDeviceTrustAfterPayments; i++ {
+		service.RecordPaymentOutcome(context.Background(), req, &PaymentResponse{Status: TransactionStatusApproved}, assessment)
+	}
+	stored, _ := repository.GetDevice(context.Background(), "tenant-1", assessment.DeviceID)
+	assert.False(t, stored.Trusted, "a revogação impede a promoção automática")
+	assert.Equal(t, "support-1", stored.TrustRevokedBy)
+
+	assessment, err = service.AssessDevice(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, DeviceTrustLevelRevoked, assessment.TrustLevel)
+	assert.False(t, assessment.SCAExemptionEligible)
+
+	t.Run("dispositivo de outro usuário", func(t *testing.T) {
+		_, err := service.LabelDevice(context.Background(), "tenant-1", "user-2", assessment.DeviceID, "Não é meu")
+		assert.ErrorIs(t, err, ErrDeviceNotFound)
+	})
+}
+
+func TestAssessDeviceTrustScoreAndSCAExemption(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		amount        float64
+		country       string
+		wantScore     int
+		wantLevel     string
+		wantExemption bool
+	}{
+		{name: "confiável e dentro do limite", amount: 50, country: "AO", wantScore: 100, wantLevel: DeviceTrustLevelHigh, wantExemption: true},
+		{name: "acima do limite de isenção", amount: 150, country: "AO", wantScore: 100, wantLevel: DeviceTrustLevelHigh},
+		{name: "mudança de país", amount: 50, country: "PT", wantScore: 80, wantLevel: DeviceTrustLevelHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repository := newTestDeviceTrustService(t, now)
+			repository.devices["dev-1"] = DeviceProfile{
+				DeviceID:           "dev-1",
+				TenantID:           "tenant-1",
+				UserID:             "user-1",
+				Fingerprint:        "fp-1",
+				DeviceType:         "mobile",
+				OperatingSystem:    "Android",
+				LastCountry:        "AO",
+				Trusted:            true,
+				SuccessfulPayments: 10,
+				FirstSeen:          now.AddDate(0, -2, 0),
+				LastSeen:           now.Add(-time.Hour),
+			}
+
+			req := devicePaymentRequest("fp-1")
+			req.Amount = tt.amount
+			req.DeviceInfo.LocationInfo.Country = tt.country
+			assessment, err := service.AssessDevice(context.Background(), req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantScore, assessment.TrustScore)
+			assert.Equal(t, tt.wantLevel, assessment.TrustLevel)
+			assert.Equal(t, tt.wantExemption, assessment.SCAExemptionEligible)
+			assert.Equal(t, tt.country != "AO", assessment.CountryChange)
+		})
+	}
+}
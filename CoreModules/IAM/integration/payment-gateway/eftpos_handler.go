@@ -0,0 +1,235 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// POSHandler expõe às equipas de operações o registo dos terminais EFTPOS, a cerimónia de injeção
+// da TMK, os avisos offline e o fecho dos lotes. O custódio de cada componente é o operador autenticado
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type POSHandler struct {
+	service *POSService
+}
+
+// NewPOSHandler cria uma nova instância do POSHandler
+func NewPOSHandler(service *POSService) *POSHandler {
+	return &POSHandler{service: service}
+}
+
+// posTerminalStatusRequest é o corpo da alteração do estado de um terminal
+type posTerminalStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *POSHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/pos/terminals", h.ListTerminals).Methods(http.MethodGet)
+	router.HandleFunc("/support/pos/terminals", h.RegisterTerminal).Methods(http.MethodPost)
+	router.HandleFunc("/support/pos/terminals/{terminalId}", h.GetTerminal).Methods(http.MethodGet)
+	router.HandleFunc("/support/pos/terminals/{terminalId}/key-components", h.SubmitKeyComponent).Methods(http.MethodPost)
+	router.HandleFunc("/support/pos/terminals/{terminalId}/status", h.SetTerminalStatus).Methods(http.MethodPut)
+	router.HandleFunc("/support/pos/terminals/{terminalId}/batches", h.ListBatches).Methods(http.MethodGet)
+	router.HandleFunc("/support/pos/terminals/{terminalId}/batches/close", h.CloseBatch).Methods(http.MethodPost)
+	router.HandleFunc("/support/pos/advices", h.ListAdvices).Methods(http.MethodGet)
+	router.HandleFunc("/support/pos/advices/forward", h.ForwardAdvices).Methods(http.MethodPost)
+}
+
+// ListTerminals lista os terminais do tenant, filtrados por merchant_id
+func (h *POSHandler) ListTerminals(w http.ResponseWriter, r *http.Request) {
+	terminals, err := h.service.ListTerminals(r.Context(), r.URL.Query().Get("merchant_id"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar terminais POS")
+		return
+	}
+
+	tenantID := r.Header.Get("X-Tenant-ID")
+	filtered := make([]*POSTerminal, 0, len(terminals))
+	for _, terminal := range terminals {
+		if terminal.TenantID == tenantID {
+			filtered = append(filtered, terminal)
+		}
+	}
+	respondWithJSON(w, http.StatusOK, filtered)
+}
+
+// RegisterTerminal regista um terminal do tenant
+func (h *POSHandler) RegisterTerminal(w http.ResponseWriter, r *http.Request) {
+	var req POSTerminalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+	req.RegisteredBy = supportOperator(r)
+
+	terminal, err := h.service.RegisterTerminal(r.Context(), req)
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao registar terminal POS")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, terminal)
+}
+
+// GetTerminal retorna o terminal sem as chaves
+func (h *POSHandler) GetTerminal(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := h.tenantTerminal(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, terminal)
+}
+
+// SubmitKeyComponent recebe o componente da TMK do operador autenticado
+func (h *POSHandler) SubmitKeyComponent(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := h.tenantTerminal(w, r)
+	if !ok {
+		return
+	}
+
+	var component POSKeyComponent
+	if err := json.NewDecoder(r.Body).Decode(&component); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	component.Custodian = supportOperator(r)
+
+	status, err := h.service.SubmitKeyComponent(r.Context(), terminal.TerminalID, component)
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao injetar componente da TMK")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, status)
+}
+
+// SetTerminalStatus suspende, reativa ou retira o terminal
+func (h *POSHandler) SetTerminalStatus(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := h.tenantTerminal(w, r)
+	if !ok {
+		return
+	}
+
+	var req posTerminalStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	terminal, err := h.service.SetTerminalStatus(r.Context(), terminal.TerminalID, req.Status, supportOperator(r))
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao alterar estado do terminal POS")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, terminal)
+}
+
+// ListBatches lista os lotes do terminal, do mais recente para o mais antigo
+func (h *POSHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := h.tenantTerminal(w, r)
+	if !ok {
+		return
+	}
+
+	batches, err := h.service.ListBatches(r.Context(), terminal.TerminalID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar lotes POS")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, batches)
+}
+
+// CloseBatch fecha o lote do terminal sem os totais do terminal
+func (h *POSHandler) CloseBatch(w http.ResponseWriter, r *http.Request) {
+	terminal, ok := h.tenantTerminal(w, r)
+	if !ok {
+		return
+	}
+
+	batch, err := h.service.CloseBatch(r.Context(), terminal.TerminalID, nil)
+	respondWithPOSBatch(w, batch, err)
+}
+
+// ListAdvices lista os avisos offline filtrados por terminal_id e status
+func (h *POSHandler) ListAdvices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	advices, err := h.service.ListAdvices(r.Context(), POSAdviceFilter{
+		TerminalID: query.Get("terminal_id"),
+		Status:     query.Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar avisos POS")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, advices)
+}
+
+// ForwardAdvices encaminha de imediato os avisos offline pendentes
+func (h *POSHandler) ForwardAdvices(w http.ResponseWriter, r *http.Request) {
+	advices, err := h.service.ForwardAdvices(r.Context(), r.URL.Query().Get("terminal_id"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao encaminhar avisos POS")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, advices)
+}
+
+// tenantTerminal retorna o terminal do caminho quando pertence ao tenant do pedido
+func (h *POSHandler) tenantTerminal(w http.ResponseWriter, r *http.Request) (*POSTerminal, bool) {
+	terminal, err := h.service.GetTerminal(r.Context(), mux.Vars(r)["terminalId"])
+	if err == nil && terminal.TenantID != r.Header.Get("X-Tenant-ID") {
+		err = ErrPOSTerminalNotFound
+	}
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao recuperar terminal POS")
+		return nil, false
+	}
+	return terminal, true
+}
+
+// respondWithPOSBatch responde com o lote fechado, também quando fica por conciliar
+func respondWithPOSBatch(w http.ResponseWriter, batch *POSBatch, err error) {
+	switch {
+	case errors.Is(err, ErrPOSBatchOutOfBalance) && batch != nil:
+		respondWithJSON(w, http.StatusConflict, batch)
+	case err != nil:
+		respondWithPOSError(w, err, "Erro ao fechar lote POS")
+	default:
+		respondWithJSON(w, http.StatusOK, batch)
+	}
+}
+
+// respondWithPOSError mapeia os erros da integração POS para respostas HTTP
+func respondWithPOSError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrPOSTerminalNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Terminal POS não encontrado")
+	case errors.Is(err, ErrPOSBatchNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrPOSInvalidMAC), errors.Is(err, ErrPOSSessionKeysExpired):
+		respondWithError(w, http.StatusUnauthorized, "terminal_unauthenticated", err.Error())
+	case errors.Is(err, ErrPOSTerminalNotActive):
+		respondWithError(w, http.StatusForbidden, "terminal_not_active", err.Error())
+	case errors.Is(err, ErrPOSTerminalExists), errors.Is(err, ErrPOSAdvicesPending):
+		respondWithError(w, http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, ErrPOSKeyInjectionRejected):
+		respondWithError(w, http.StatusUnprocessableEntity, "key_injection_rejected", err.Error())
+	case errors.Is(err, ErrPOSOfflineLimitExceeded), errors.Is(err, ErrPOSDeclined):
+		respondWithError(w, http.StatusUnprocessableEntity, "pos_declined", err.Error())
+	case errors.Is(err, ErrPOSHostUnavailable), errors.Is(err, ErrPOSNetworkNotConfigured), errors.Is(err, ErrISO8583Invalid):
+		respondWithError(w, http.StatusBadGateway, "network_unavailable", err.Error())
+	case errors.Is(err, ErrPOSTerminalInvalid), errors.Is(err, ErrPOSInvalidSale), errors.Is(err, ErrPOSInvalidPINBlock):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
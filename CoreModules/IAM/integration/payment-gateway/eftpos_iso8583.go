@@ -0,0 +1,254 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iso8583Field descreve o formato ASCII de um campo: comprimento fixo ou, com prefixo LL/LLL, máximo
+type iso8583Field struct {
+	length  int
+	prefix  int // Dígitos do prefixo de comprimento; 0 nos campos de comprimento fixo
+	numeric bool
+}
+
+// iso8583Fields são os campos usados nas mensagens com as redes EMIS e SIMO
+var iso8583Fields = map[int]iso8583Field{
+	2:  {19, 2, true},  // PAN
+	3:  {6, 0, true},   // Código de processamento
+	4:  {12, 0, true},  // Valor em unidades menores da moeda
+	7:  {10, 0, true},  // Data e hora da transmissão (MMDDhhmmss, UTC)
+	11: {6, 0, true},   // STAN
+	12: {6, 0, true},   // Hora local do terminal (hhmmss)
+	13: {4, 0, true},   // Data local do terminal (MMDD)
+	14: {4, 0, true},   // Validade do cartão (AAMM)
+	22: {3, 0, true},   // Modo de entrada do PAN e capacidade de PIN
+	32: {11, 2, true},  // Identificação do adquirente
+	37: {12, 0, false}, // RRN
+	38: {6, 0, false},  // Código de autorização
+	39: {2, 0, false},  // Código de resposta
+	41: {8, 0, false},  // TID
+	42: {15, 0, false}, // Identificação do aceitante (MID)
+	49: {3, 0, true},   // Moeda (ISO 4217 numérico)
+	52: {16, 0, false}, // PIN block cifrado com a ZPK (hex)
+	60: {6, 3, true},   // Número do lote
+	74: {10, 0, true},  // Número de créditos
+	76: {10, 0, true},  // Número de débitos
+	86: {16, 0, true},  // Valor dos créditos
+	88: {16, 0, true},  // Valor dos débitos
+}
+
+// ISO8583Message é uma mensagem ISO 8583 em ASCII: MTI, bitmap em hexadecimal (com bitmap secundário
+// quando há campos acima de 64) e os campos por ordem
+type ISO8583Message struct {
+	MTI    string         `json:"mti"`
+	Fields map[int]string `json:"fields"`
+}
+
+// NewISO8583Message cria uma mensagem vazia do tipo indicado
+func NewISO8583Message(mti string) *ISO8583Message {
+	return &ISO8583Message{MTI: mti, Fields: make(map[int]string)}
+}
+
+// Set define o valor de um campo; valores vazios não são incluídos na mensagem
+func (m *ISO8583Message) Set(field int, value string) *ISO8583Message {
+	if value != "" {
+		m.Fields[field] = value
+	}
+	return m
+}
+
+// Get retorna o valor de um campo ou vazio quando ausente
+func (m *ISO8583Message) Get(field int) string {
+	return m.Fields[field]
+}
+
+// Pack codifica a mensagem, validando o formato de cada campo
+func (m *ISO8583Message) Pack() ([]byte, error) {
+	if len(m.MTI) != 4 || !posDigits(m.MTI) {
+		return nil, fmt.Errorf("%w: MTI %q", ErrISO8583Invalid, m.MTI)
+	}
+
+	fields := make([]int, 0, len(m.Fields))
+	for field := range m.Fields {
+		if _, known := iso8583Fields[field]; !known {
+			return nil, fmt.Errorf("%w: campo %d não suportado", ErrISO8583Invalid, field)
+		}
+		fields = append(fields, field)
+	}
+	sort.Ints(fields)
+
+	bitmap := make([]byte, 8)
+	if len(fields) > 0 && fields[len(fields)-1] > 64 {
+		bitmap = make([]byte, 16)
+		bitmap[0] |= 0x80
+	}
+	var body strings.Builder
+	for _, field := range fields {
+		bitmap[(field-1)/8] |= 0x80 >> uint((field-1)%8)
+		value, err := iso8583Fields[field].encode(m.Fields[field])
+		if err != nil {
+			return nil, fmt.Errorf("%w: campo %d: %v", ErrISO8583Invalid, field, err)
+		}
+		body.WriteString(value)
+	}
+	return []byte(m.MTI + strings.ToUpper(hex.EncodeToString(bitmap)) + body.String()), nil
+}
+
+// encode formata o valor: numéricos fixos com zeros à esquerda, alfanuméricos fixos com espaços à direita
+func (f iso8583Field) encode(value string) (string, error) {
+	if f.numeric && !posDigits(value) {
+		return "", errors.New("valor não numérico")
+	}
+	if len(value) > f.length {
+		return "", fmt.Errorf("comprimento %d acima de %d", len(value), f.length)
+	}
+	if f.prefix > 0 {
+		return fmt.Sprintf("%0*d%s", f.prefix, len(value), value), nil
+	}
+	if f.numeric {
+		return strings.Repeat("0", f.length-len(value)) + value, nil
+	}
+	return value + strings.Repeat(" ", f.length-len(value)), nil
+}
+
+// UnpackISO8583 decodifica uma mensagem recebida de uma rede
+func UnpackISO8583(data []byte) (*ISO8583Message, error) {
+	raw := string(data)
+	if len(raw) < 20 || !posDigits(raw[:4]) {
+		return nil, fmt.Errorf("%w: cabeçalho incompleto", ErrISO8583Invalid)
+	}
+	bitmap, err := hex.DecodeString(raw[4:20])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bitmap primário", ErrISO8583Invalid)
+	}
+	offset := 20
+	if bitmap[0]&0x80 != 0 {
+		if len(raw) < 36 {
+			return nil, fmt.Errorf("%w: bitmap secundário incompleto", ErrISO8583Invalid)
+		}
+		secondary, err := hex.DecodeString(raw[20:36])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bitmap secundário", ErrISO8583Invalid)
+		}
+		bitmap = append(bitmap, secondary...)
+		offset = 36
+	}
+
+	message := NewISO8583Message(raw[:4])
+	for field := 2; field <= len(bitmap)*8; field++ {
+		if bitmap[(field-1)/8]&(0x80>>uint((field-1)%8)) == 0 {
+			continue
+		}
+		spec, known := iso8583Fields[field]
+		if !known {
+			return nil, fmt.Errorf("%w: campo %d não suportado", ErrISO8583Invalid, field)
+		}
+		length := spec.length
+		if spec.prefix > 0 {
+			if offset+spec.prefix > len(raw) {
+				return nil, fmt.Errorf("%w: campo %d truncado", ErrISO8583Invalid, field)
+			}
+			length, err = strconv.Atoi(raw[offset : offset+spec.prefix])
+			if err != nil || length > spec.length {
+				return nil, fmt.Errorf("%w: comprimento do campo %d", ErrISO8583Invalid, field)
+			}
+			offset += spec.prefix
+		}
+		if offset+length > len(raw) {
+			return nil, fmt.Errorf("%w: campo %d truncado", ErrISO8583Invalid, field)
+		}
+		value := raw[offset : offset+length]
+		offset += length
+		if spec.prefix == 0 && !spec.numeric {
+			value = strings.TrimRight(value, " ")
+		}
+		message.Fields[field] = value
+	}
+	if offset != len(raw) {
+		return nil, fmt.Errorf("%w: %d bytes após o último campo", ErrISO8583Invalid, len(raw)-offset)
+	}
+	return message, nil
+}
+
+// posDigits indica se o valor é composto apenas por dígitos
+func posDigits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ISO8583Host troca mensagens ISO 8583 com o host de autorização de uma rede. Falhas de ligação e
+// tempo esgotado são retornados com ErrPOSHostUnavailable, que ativa a aprovação offline
+type ISO8583Host interface {
+	Exchange(ctx context.Context, request *ISO8583Message) (*ISO8583Message, error)
+}
+
+// TCPISO8583Host envia cada mensagem numa ligação TCP, precedida do comprimento em 2 bytes (big-endian)
+type TCPISO8583Host struct {
+	addr    string
+	timeout time.Duration
+	dialer  net.Dialer
+}
+
+// NewTCPISO8583Host cria a ligação ao host da rede configurada
+func NewTCPISO8583Host(network POSNetworkConfig) *TCPISO8583Host {
+	timeout := network.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPOSHostTimeout
+	}
+	return &TCPISO8583Host{addr: network.HostAddr, timeout: timeout}
+}
+
+// Exchange envia o pedido e aguarda a resposta dentro do tempo máximo da rede
+func (h *TCPISO8583Host) Exchange(ctx context.Context, request *ISO8583Message) (*ISO8583Message, error) {
+	payload, err := request.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	conn, err := h.dialer.DialContext(ctx, "tcp", h.addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPOSHostUnavailable, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	frame := make([]byte, 2+len(payload))
+	binary.BigEndian.PutUint16(frame, uint16(len(payload)))
+	copy(frame[2:], payload)
+	if _, err := conn.Write(frame); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPOSHostUnavailable, err)
+	}
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPOSHostUnavailable, err)
+	}
+	size := binary.BigEndian.Uint16(header)
+	if size == 0 || size > posMaxMessageSize {
+		return nil, fmt.Errorf("%w: comprimento %d", ErrISO8583Invalid, size)
+	}
+	response := make([]byte, size)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPOSHostUnavailable, err)
+	}
+	return UnpackISO8583(response)
+}
@@ -0,0 +1,127 @@
+package paymentgateway
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// posTripleDES cria a cifra 3DES de uma chave de comprimento duplo (K1|K2|K1)
+func posTripleDES(key []byte) (cipher.Block, error) {
+	if len(key) != 16 {
+		return nil, errors.New("chave 3DES deve ter 16 bytes")
+	}
+	return des.NewTripleDESCipher(append(append([]byte(nil), key...), key[:8]...))
+}
+
+// posECB cifra ou decifra blocos de 8 bytes em ECB, usado para chaves sob chave e PIN blocks
+func posECB(key, data []byte, decrypt bool) ([]byte, error) {
+	block, err := posTripleDES(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("dados fora do tamanho do bloco 3DES")
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += block.BlockSize() {
+		if decrypt {
+			block.Decrypt(out[i:], data[i:])
+		} else {
+			block.Encrypt(out[i:], data[i:])
+		}
+	}
+	return out, nil
+}
+
+// posKCV calcula o key check value: os 3 primeiros bytes da cifra de um bloco de zeros, em hexadecimal
+func posKCV(key []byte) (string, error) {
+	encrypted, err := posECB(key, make([]byte, 8), false)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(encrypted[:3])), nil
+}
+
+// posGenerateKey gera uma chave 3DES de comprimento duplo com paridade ímpar em cada byte
+func posGenerateKey() ([]byte, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	for i, b := range key {
+		b &= 0xFE
+		ones := 0
+		for bit := 1; bit < 8; bit++ {
+			ones += int(b>>uint(bit)) & 1
+		}
+		if ones%2 == 0 {
+			b |= 1
+		}
+		key[i] = b
+	}
+	return key, nil
+}
+
+// posRetailMAC calcula o MAC ISO 9797-1 algoritmo 3 (retail MAC, ANSI X9.19) com preenchimento método 2
+func posRetailMAC(key, data []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errors.New("chave de MAC deve ter 16 bytes")
+	}
+	left, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, err
+	}
+	right, err := des.NewCipher(key[8:])
+	if err != nil {
+		return nil, err
+	}
+
+	padded := append(append([]byte(nil), data...), 0x80)
+	for len(padded)%8 != 0 {
+		padded = append(padded, 0)
+	}
+	mac := make([]byte, 8)
+	for i := 0; i < len(padded); i += 8 {
+		for j := 0; j < 8; j++ {
+			mac[j] ^= padded[i+j]
+		}
+		left.Encrypt(mac, mac)
+	}
+	right.Decrypt(mac, mac)
+	left.Encrypt(mac, mac)
+	return mac, nil
+}
+
+// posTranslatePINBlock decifra o PIN block com a TPK do terminal, verifica o formato ISO 9564 0 e
+// cifra-o com a ZPK da rede, sem expor o PIN fora desta função
+func posTranslatePINBlock(pinBlock string, tpk []byte, zpkHex string) (string, error) {
+	encrypted, err := hex.DecodeString(pinBlock)
+	if err != nil || len(encrypted) != 8 {
+		return "", fmt.Errorf("%w: esperados 16 caracteres hexadecimais", ErrPOSInvalidPINBlock)
+	}
+	zpk, err := hex.DecodeString(zpkHex)
+	if err != nil || len(zpk) != 16 {
+		return "", fmt.Errorf("%w: ZPK da rede ausente", ErrPOSNetworkNotConfigured)
+	}
+
+	plain, err := posECB(tpk, encrypted, true)
+	if err != nil {
+		return "", err
+	}
+	if format, length := plain[0]>>4, plain[0]&0x0F; format != 0 || length < 4 || length > 12 {
+		return "", fmt.Errorf("%w: formato ISO 9564 0 esperado", ErrPOSInvalidPINBlock)
+	}
+	translated, err := posECB(zpk, plain, false)
+	for i := range plain {
+		plain[i] = 0
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(translated)), nil
+}
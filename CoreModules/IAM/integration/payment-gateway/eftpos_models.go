@@ -0,0 +1,334 @@
+package paymentgateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Redes de pagamento com cartão presente (EFTPOS) por mercado
+const (
+	POSNetworkEMIS = "emis" // Multicaixa (EMIS), Angola
+	POSNetworkSIMO = "simo" // Rede interbancária SIMO, Moçambique
+)
+
+// Estados de um terminal POS
+const (
+	POSTerminalStatusPendingKeys    = "pending_key_injection" // Registado, a aguardar a injeção da TMK
+	POSTerminalStatusActive         = "active"
+	POSTerminalStatusSuspended      = "suspended"
+	POSTerminalStatusDecommissioned = "decommissioned" // Retirado de serviço; as chaves são apagadas
+)
+
+// Estados de um lote de liquidação de um terminal
+const (
+	POSBatchStatusOpen         = "open"
+	POSBatchStatusClosing      = "closing" // Fecho em curso com a rede; novas vendas abrem outro lote
+	POSBatchStatusSettled      = "settled"
+	POSBatchStatusOutOfBalance = "out_of_balance" // Totais divergentes do terminal ou da rede depois do envio detalhado
+)
+
+// Estados de um aviso de transação aprovada offline (0220)
+const (
+	POSAdviceStatusPending   = "pending"
+	POSAdviceStatusForwarded = "forwarded"
+	POSAdviceStatusRejected  = "rejected" // Recusado pelo emissor; o risco fica com o comerciante
+)
+
+// Origens das aprovações offline
+const (
+	POSOfflineReasonStandIn  = "stand_in"         // Aprovada pelo gateway com o host da rede indisponível
+	POSOfflineReasonTerminal = "terminal_offline" // Aprovada pelo terminal sem ligação ao gateway e enviada depois
+)
+
+// Tipos de mensagem ISO 8583 (versão 1987) trocados com as redes
+const (
+	ISO8583MTIAuthorizationRequest  = "0200"
+	ISO8583MTIAuthorizationResponse = "0210"
+	ISO8583MTIAdvice                = "0220"
+	ISO8583MTIAdviceResponse        = "0230"
+	ISO8583MTIBatchUpload           = "0320"
+	ISO8583MTIBatchUploadResponse   = "0330"
+	ISO8583MTIReconciliation        = "0500"
+	ISO8583MTIReconciliationResp    = "0510"
+)
+
+// Códigos de resposta ISO 8583 (campo 39) tratados pelo gateway
+const (
+	ISO8583ResponseApproved          = "00"
+	ISO8583ResponseDeclined          = "05"
+	ISO8583ResponseIssuerUnavailable = "91"
+	ISO8583ResponseReconcileError    = "95" // Totais do fecho divergentes: a rede pede o envio detalhado do lote
+)
+
+// Cabeçalhos da API dos terminais
+const (
+	POSMACHeader    = "X-POS-MAC"         // MAC (ISO 9797-1 algoritmo 3) do corpo calculado com a TAK
+	POSSerialHeader = "X-Terminal-Serial" // Número de série do terminal na troca das chaves de sessão
+)
+
+// Valores padrão da integração POS
+const (
+	DefaultPOSSessionKeyLifetime = 24 * time.Hour   // Validade das chaves de sessão TPK/TAK
+	DefaultPOSHostTimeout        = 15 * time.Second // Tempo máximo por troca de mensagens com a rede
+	DefaultPOSAdviceInterval     = time.Minute      // Periodicidade do encaminhamento dos avisos offline
+
+	posKeyComponentsRequired = 2 // Componentes da TMK, de custódios distintos (controlo duplo)
+	posMaxMessageSize        = 8192
+)
+
+// Erros da integração POS
+var (
+	ErrPOSTerminalNotFound     = errors.New("terminal POS não encontrado")
+	ErrPOSTerminalExists       = errors.New("terminal POS já registado")
+	ErrPOSTerminalInvalid      = errors.New("dados do terminal POS inválidos")
+	ErrPOSTerminalNotActive    = errors.New("terminal POS não está ativo")
+	ErrPOSKeyInjectionRejected = errors.New("injeção da chave mestra recusada")
+	ErrPOSSessionKeysExpired   = errors.New("chaves de sessão do terminal ausentes ou expiradas")
+	ErrPOSInvalidMAC           = errors.New("MAC da mensagem do terminal inválido")
+	ErrPOSInvalidSale          = errors.New("pedido de venda POS inválido")
+	ErrPOSInvalidPINBlock      = errors.New("PIN block inválido")
+	ErrPOSDeclined             = errors.New("transação recusada pela rede")
+	ErrPOSHostUnavailable      = errors.New("host da rede indisponível")
+	ErrPOSNetworkNotConfigured = errors.New("rede POS não configurada")
+	ErrPOSOfflineLimitExceeded = errors.New("limites de aprovação offline do terminal excedidos")
+	ErrPOSAuthorizationMissing = errors.New("autorização POS não encontrada")
+	ErrPOSAdvicesPending       = errors.New("avisos offline por encaminhar à rede")
+	ErrPOSBatchNotFound        = errors.New("nenhum lote por liquidar no terminal")
+	ErrPOSBatchOutOfBalance    = errors.New("totais do lote divergentes")
+	ErrISO8583Invalid          = errors.New("mensagem ISO 8583 inválida")
+)
+
+// POSDeclinedError é a recusa de uma transação pela rede, com o código de resposta do campo 39
+type POSDeclinedError struct {
+	ResponseCode string
+}
+
+func (e *POSDeclinedError) Error() string {
+	return fmt.Sprintf("%v (código %s)", ErrPOSDeclined, e.ResponseCode)
+}
+
+func (e *POSDeclinedError) Unwrap() error {
+	return ErrPOSDeclined
+}
+
+// POSNetworkConfig configura a ligação ISO 8583 à rede de pagamentos de um mercado
+type POSNetworkConfig struct {
+	Network      string        `json:"network"`
+	Currency     string        `json:"currency"`      // Moeda das transações na rede
+	CurrencyCode string        `json:"currency_code"` // ISO 4217 numérico (campo 49)
+	HostAddr     string        `json:"host_addr"`     // host:porta do host de autorização; vazio desativa a ligação
+	AcquirerID   string        `json:"acquirer_id"`   // Identificação do adquirente na rede (campo 32)
+	ZPK          string        `json:"-"`             // Chave de zona 3DES (hex) partilhada com a rede para os PIN blocks
+	Timeout      time.Duration `json:"timeout"`       // Tempo máximo por troca de mensagens (padrão 15s)
+}
+
+// DefaultPOSNetworks retorna as redes EFTPOS por mercado: EMIS em Angola e SIMO em Moçambique. O
+// endereço do host, o adquirente e a ZPK são indicados em POSConfig.Networks
+func DefaultPOSNetworks() map[string]POSNetworkConfig {
+	return map[string]POSNetworkConfig{
+		RegionAngola:     {Network: POSNetworkEMIS, Currency: "AOA", CurrencyCode: "973"},
+		RegionMozambique: {Network: POSNetworkSIMO, Currency: "MZN", CurrencyCode: "943"},
+	}
+}
+
+// POSOfflineLimits limita as aprovações offline de um terminal ainda não encaminhadas à rede
+type POSOfflineLimits struct {
+	FloorLimit      float64 `json:"floor_limit"`      // Valor máximo de uma transação aprovada offline
+	CumulativeLimit float64 `json:"cumulative_limit"` // Valor acumulado máximo por encaminhar
+	MaxCount        int     `json:"max_count"`        // Número máximo de transações por encaminhar
+}
+
+// DefaultPOSOfflineLimits retorna os limites offline por mercado, na moeda da rede; os limites
+// próprios de um terminal não podem excedê-los
+func DefaultPOSOfflineLimits() map[string]POSOfflineLimits {
+	return map[string]POSOfflineLimits{
+		RegionAngola:     {FloorLimit: 50000, CumulativeLimit: 500000, MaxCount: 20},
+		RegionMozambique: {FloorLimit: 3500, CumulativeLimit: 35000, MaxCount: 20},
+	}
+}
+
+// POSConfig contém as configurações dos terminais EFTPOS
+type POSConfig struct {
+	// Ligação às redes por mercado, sobre DefaultPOSNetworks
+	Networks map[string]POSNetworkConfig `json:"networks"`
+
+	// Limites das aprovações offline por mercado, sobre DefaultPOSOfflineLimits
+	OfflineLimits map[string]POSOfflineLimits `json:"offline_limits"`
+
+	// Chave AES-256 (hex) que cifra as chaves dos terminais em repouso; sem chave é gerada uma
+	// chave efémera e as chaves injetadas perdem-se no reinício
+	KeyEncryptionKey string `json:"-"`
+
+	SessionKeyLifetime time.Duration `json:"session_key_lifetime"`
+	AdviceInterval     time.Duration `json:"advice_interval"`
+}
+
+// POSTerminal é um terminal de pagamento de um comerciante, ligado à rede do seu mercado
+type POSTerminal struct {
+	TerminalID          string           `json:"terminal_id" db:"terminal_id"` // TID na rede (campo 41), 8 caracteres
+	TenantID            string           `json:"tenant_id" db:"tenant_id"`
+	MerchantID          string           `json:"merchant_id" db:"merchant_id"`
+	CardAcceptorID      string           `json:"card_acceptor_id" db:"card_acceptor_id"` // Identificação do aceitante na rede (campo 42)
+	RegionCode          string           `json:"region_code" db:"region_code"`
+	Network             string           `json:"network" db:"network"`
+	SerialNumber        string           `json:"serial_number" db:"serial_number"`
+	Model               string           `json:"model,omitempty" db:"model"`
+	Location            string           `json:"location,omitempty" db:"location"`
+	Status              string           `json:"status" db:"status"`
+	OfflineLimits       POSOfflineLimits `json:"offline_limits" db:"-"`
+	MasterKeyKCV        string           `json:"master_key_kcv,omitempty" db:"master_key_kcv"` // KCV da TMK injetada
+	KeyCustodians       []string         `json:"key_custodians,omitempty" db:"-"`              // Custódios da última injeção da TMK
+	KeyInjectedAt       *time.Time       `json:"key_injected_at,omitempty" db:"key_injected_at"`
+	SessionKeysIssuedAt *time.Time       `json:"session_keys_issued_at,omitempty" db:"session_keys_issued_at"`
+	SessionKeysExpireAt *time.Time       `json:"session_keys_expire_at,omitempty" db:"session_keys_expire_at"`
+	RegisteredBy        string           `json:"registered_by,omitempty" db:"registered_by"`
+	RegisteredAt        time.Time        `json:"registered_at" db:"registered_at"`
+	UpdatedAt           time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Chaves cifradas com a chave de cifra do gateway; nunca expostas na API
+	MasterKey []byte `json:"-" db:"master_key"` // TMK
+	PINKey    []byte `json:"-" db:"pin_key"`    // TPK
+	MACKey    []byte `json:"-" db:"mac_key"`    // TAK
+}
+
+// POSTerminalRequest é o pedido de registo de um terminal
+type POSTerminalRequest struct {
+	TerminalID     string            `json:"terminal_id"`
+	TenantID       string            `json:"-"`
+	MerchantID     string            `json:"merchant_id"`
+	CardAcceptorID string            `json:"card_acceptor_id"`
+	RegionCode     string            `json:"region_code"`
+	SerialNumber   string            `json:"serial_number"`
+	Model          string            `json:"model,omitempty"`
+	Location       string            `json:"location,omitempty"`
+	OfflineLimits  *POSOfflineLimits `json:"offline_limits,omitempty"` // Padrão: limites do mercado
+	RegisteredBy   string            `json:"-"`
+}
+
+// POSKeyComponent é o componente da TMK introduzido por um custódio na cerimónia de chaves
+type POSKeyComponent struct {
+	Component string `json:"component"`  // Componente 3DES de comprimento duplo (hex)
+	KCV       string `json:"kcv"`        // KCV do componente
+	MasterKCV string `json:"master_kcv"` // KCV esperado da TMK composta, do formulário da cerimónia
+	Custodian string `json:"-"`          // Operador autenticado na API de suporte
+}
+
+// POSPendingKeyComponent é um componente da TMK recebido e ainda não composto, cifrado em repouso
+type POSPendingKeyComponent struct {
+	TerminalID string    `db:"terminal_id"`
+	Custodian  string    `db:"custodian"`
+	MasterKCV  string    `db:"master_kcv"`
+	Component  []byte    `db:"component"` // Componente cifrado com a chave de cifra do gateway
+	ReceivedAt time.Time `db:"received_at"`
+}
+
+// POSKeyInjectionStatus é o estado da injeção da TMK depois de um componente
+type POSKeyInjectionStatus struct {
+	TerminalID         string       `json:"terminal_id"`
+	ComponentsReceived int          `json:"components_received"`
+	ComponentsRequired int          `json:"components_required"`
+	Custodians         []string     `json:"custodians"`
+	Completed          bool         `json:"completed"`
+	Terminal           *POSTerminal `json:"terminal"`
+}
+
+// POSSessionKeys são as chaves de sessão de um terminal, cifradas com a sua TMK
+type POSSessionKeys struct {
+	TerminalID string    `json:"terminal_id"`
+	TPK        string    `json:"tpk"` // Chave de PIN cifrada com a TMK (hex)
+	TPKKCV     string    `json:"tpk_kcv"`
+	TAK        string    `json:"tak"` // Chave de MAC cifrada com a TMK (hex)
+	TAKKCV     string    `json:"tak_kcv"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// POSSaleRequest é o pedido de venda enviado pelo terminal, autenticado com a TAK
+type POSSaleRequest struct {
+	TransactionID   string    `json:"transaction_id,omitempty"` // Gerado quando ausente
+	STAN            string    `json:"stan"`                     // Número de sequência do terminal (6 dígitos)
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	PAN             string    `json:"pan"`
+	Expiry          string    `json:"expiry"`              // Validade do cartão (AAMM)
+	EntryMode       string    `json:"entry_mode"`          // Campo 22 (ex.: "051" chip com PIN, "071" contactless)
+	PINBlock        string    `json:"pin_block,omitempty"` // PIN block ISO 9564 formato 0 cifrado com a TPK (hex)
+	LocalTime       time.Time `json:"local_time"`
+	OfflineAuthCode string    `json:"offline_auth_code,omitempty"` // Aprovação offline pelo terminal, enviada depois
+}
+
+// POSAuthorization é o desfecho de uma venda num terminal
+type POSAuthorization struct {
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	TerminalID    string    `json:"terminal_id" db:"terminal_id"`
+	Network       string    `json:"network" db:"network"`
+	STAN          string    `json:"stan" db:"stan"`
+	RRN           string    `json:"rrn" db:"rrn"`
+	AuthCode      string    `json:"auth_code,omitempty" db:"auth_code"`
+	ResponseCode  string    `json:"response_code" db:"response_code"`
+	Approved      bool      `json:"approved" db:"approved"`
+	Offline       bool      `json:"offline" db:"offline"`
+	OfflineReason string    `json:"offline_reason,omitempty" db:"offline_reason"`
+	MaskedPAN     string    `json:"masked_pan" db:"masked_pan"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	BatchNumber   int       `json:"batch_number" db:"batch_number"`
+	AuthorizedAt  time.Time `json:"authorized_at" db:"authorized_at"`
+}
+
+// POSAdvice é o aviso (0220) de uma transação aprovada offline, guardado até ser aceite pela rede
+type POSAdvice struct {
+	ID            string          `json:"id" db:"id"`
+	TerminalID    string          `json:"terminal_id" db:"terminal_id"`
+	TransactionID string          `json:"transaction_id" db:"transaction_id"`
+	Network       string          `json:"network" db:"network"`
+	Amount        float64         `json:"amount" db:"amount"`
+	Currency      string          `json:"currency" db:"currency"`
+	Reason        string          `json:"reason" db:"reason"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	ResponseCode  string          `json:"response_code,omitempty" db:"response_code"`
+	LastError     string          `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	ForwardedAt   *time.Time      `json:"forwarded_at,omitempty" db:"forwarded_at"`
+	Message       *ISO8583Message `json:"-" db:"-"` // Mensagem 0220 enviada à rede
+}
+
+// POSAdviceFilter filtra a listagem dos avisos offline
+type POSAdviceFilter struct {
+	TerminalID string
+	Status     string
+}
+
+// POSBatchTotals são os totais de débitos (vendas) de um lote
+type POSBatchTotals struct {
+	DebitCount  int     `json:"debit_count"`
+	DebitAmount float64 `json:"debit_amount"`
+}
+
+// POSBatchEntry é uma venda aprovada incluída num lote
+type POSBatchEntry struct {
+	TransactionID string  `json:"transaction_id"`
+	STAN          string  `json:"stan"`
+	RRN           string  `json:"rrn"`
+	AuthCode      string  `json:"auth_code"`
+	Amount        float64 `json:"amount"`
+	Offline       bool    `json:"offline"`
+	Rejected      bool    `json:"rejected,omitempty"` // Aviso offline recusado pelo emissor; fora dos totais
+}
+
+// POSBatch é o lote de liquidação de um terminal, fechado com a rede por totais (0500)
+type POSBatch struct {
+	TerminalID     string          `json:"terminal_id" db:"terminal_id"`
+	Network        string          `json:"network" db:"network"`
+	BatchNumber    int             `json:"batch_number" db:"batch_number"`
+	Status         string          `json:"status" db:"status"`
+	Totals         POSBatchTotals  `json:"totals" db:"-"`
+	TerminalTotals *POSBatchTotals `json:"terminal_totals,omitempty" db:"-"` // Totais indicados pelo terminal no fecho
+	Entries        []POSBatchEntry `json:"entries" db:"-"`
+	Uploaded       bool            `json:"uploaded" db:"uploaded"` // Enviado em detalhe (0320) depois de a rede recusar os totais
+	ResponseCode   string          `json:"response_code,omitempty" db:"response_code"`
+	OpenedAt       time.Time       `json:"opened_at" db:"opened_at"`
+	ClosedAt       *time.Time      `json:"closed_at,omitempty" db:"closed_at"`
+}
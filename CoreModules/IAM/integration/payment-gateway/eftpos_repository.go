@@ -0,0 +1,367 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresPOSStore implementa POSStore para PostgreSQL
+type PostgresPOSStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresPOSStore cria uma nova instância de PostgresPOSStore
+func NewPostgresPOSStore(db *sqlx.DB) *PostgresPOSStore {
+	return &PostgresPOSStore{db: db}
+}
+
+// dbPOSTerminal é a representação de POSTerminal na base de dados
+type dbPOSTerminal struct {
+	POSTerminal
+	OfflineLimitsJSON []byte `db:"offline_limits"`
+	KeyCustodiansJSON []byte `db:"key_custodians"`
+}
+
+// dbPOSAdvice é a representação de POSAdvice na base de dados
+type dbPOSAdvice struct {
+	POSAdvice
+	MessageJSON []byte `db:"message"`
+}
+
+// dbPOSBatch é a representação de POSBatch na base de dados
+type dbPOSBatch struct {
+	POSBatch
+	TotalsJSON         []byte `db:"totals"`
+	TerminalTotalsJSON []byte `db:"terminal_totals"`
+	EntriesJSON        []byte `db:"entries"`
+}
+
+// posTerminalColumns são as colunas lidas nas consultas de terminais
+const posTerminalColumns = `terminal_id, tenant_id, merchant_id, card_acceptor_id, region_code, network, serial_number,
+	model, location, status, offline_limits, master_key_kcv, key_custodians, key_injected_at, session_keys_issued_at,
+	session_keys_expire_at, registered_by, registered_at, updated_at, master_key, pin_key, mac_key`
+
+// posAdviceColumns são as colunas lidas nas consultas de avisos
+const posAdviceColumns = `id, terminal_id, transaction_id, network, amount, currency, reason, status, attempts,
+	response_code, last_error, created_at, forwarded_at, message`
+
+// posBatchColumns são as colunas lidas nas consultas de lotes
+const posBatchColumns = `terminal_id, network, batch_number, status, totals, terminal_totals, entries, uploaded,
+	response_code, opened_at, closed_at`
+
+// SavePOSTerminal grava o terminal, substituindo o estado anterior
+func (r *PostgresPOSStore) SavePOSTerminal(ctx context.Context, terminal *POSTerminal) error {
+	row := &dbPOSTerminal{POSTerminal: *terminal}
+
+	var err error
+	if row.OfflineLimitsJSON, err = json.Marshal(terminal.OfflineLimits); err != nil {
+		return fmt.Errorf("falha ao codificar limites offline: %w", err)
+	}
+	if row.KeyCustodiansJSON, err = json.Marshal(terminal.KeyCustodians); err != nil {
+		return fmt.Errorf("falha ao codificar custódios: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.pos_terminals (
+			terminal_id, tenant_id, merchant_id, card_acceptor_id, region_code, network, serial_number,
+			model, location, status, offline_limits, master_key_kcv, key_custodians, key_injected_at, session_keys_issued_at,
+			session_keys_expire_at, registered_by, registered_at, updated_at, master_key, pin_key, mac_key
+		) VALUES (
+			:terminal_id, :tenant_id, :merchant_id, :card_acceptor_id, :region_code, :network, :serial_number,
+			:model, :location, :status, :offline_limits, :master_key_kcv, :key_custodians, :key_injected_at, :session_keys_issued_at,
+			:session_keys_expire_at, :registered_by, :registered_at, :updated_at, :master_key, :pin_key, :mac_key
+		)
+		ON CONFLICT (terminal_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			master_key_kcv = EXCLUDED.master_key_kcv,
+			key_custodians = EXCLUDED.key_custodians,
+			key_injected_at = EXCLUDED.key_injected_at,
+			session_keys_issued_at = EXCLUDED.session_keys_issued_at,
+			session_keys_expire_at = EXCLUDED.session_keys_expire_at,
+			updated_at = EXCLUDED.updated_at,
+			master_key = EXCLUDED.master_key,
+			pin_key = EXCLUDED.pin_key,
+			mac_key = EXCLUDED.mac_key
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar terminal POS: %w", err)
+	}
+
+	return nil
+}
+
+// GetPOSTerminal recupera o terminal
+func (r *PostgresPOSStore) GetPOSTerminal(ctx context.Context, terminalID string) (*POSTerminal, error) {
+	var row dbPOSTerminal
+	query := `SELECT ` + posTerminalColumns + ` FROM payment_gateway.pos_terminals WHERE terminal_id = $1`
+	if err := r.db.GetContext(ctx, &row, query, terminalID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPOSTerminalNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar terminal POS: %w", err)
+	}
+	return row.decode()
+}
+
+// ListPOSTerminals lista os terminais do comerciante (todos quando vazio) ordenados pelo TID
+func (r *PostgresPOSStore) ListPOSTerminals(ctx context.Context, merchantID string) ([]*POSTerminal, error) {
+	var rows []dbPOSTerminal
+	query := `SELECT ` + posTerminalColumns + ` FROM payment_gateway.pos_terminals
+		WHERE $1 = '' OR merchant_id = $1
+		ORDER BY terminal_id`
+	if err := r.db.SelectContext(ctx, &rows, query, merchantID); err != nil {
+		return nil, fmt.Errorf("falha ao listar terminais POS: %w", err)
+	}
+
+	terminals := make([]*POSTerminal, 0, len(rows))
+	for i := range rows {
+		terminal, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		terminals = append(terminals, terminal)
+	}
+	return terminals, nil
+}
+
+// SavePOSKeyComponent grava o componente da TMK, substituindo o anterior do mesmo custódio
+func (r *PostgresPOSStore) SavePOSKeyComponent(ctx context.Context, component *POSPendingKeyComponent) error {
+	query := `
+		INSERT INTO payment_gateway.pos_key_components (terminal_id, custodian, master_kcv, component, received_at)
+		VALUES (:terminal_id, :custodian, :master_kcv, :component, :received_at)
+		ON CONFLICT (terminal_id, custodian) DO UPDATE SET
+			master_kcv = EXCLUDED.master_kcv,
+			component = EXCLUDED.component,
+			received_at = EXCLUDED.received_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, component); err != nil {
+		return fmt.Errorf("falha ao gravar componente da TMK: %w", err)
+	}
+
+	return nil
+}
+
+// ListPOSKeyComponents lista os componentes da TMK por compor do terminal, por ordem de receção
+func (r *PostgresPOSStore) ListPOSKeyComponents(ctx context.Context, terminalID string) ([]*POSPendingKeyComponent, error) {
+	components := make([]*POSPendingKeyComponent, 0)
+	query := `SELECT terminal_id, custodian, master_kcv, component, received_at
+		FROM payment_gateway.pos_key_components
+		WHERE terminal_id = $1
+		ORDER BY received_at`
+	if err := r.db.SelectContext(ctx, &components, query, terminalID); err != nil {
+		return nil, fmt.Errorf("falha ao listar componentes da TMK: %w", err)
+	}
+	return components, nil
+}
+
+// DeletePOSKeyComponents descarta os componentes da TMK por compor do terminal
+func (r *PostgresPOSStore) DeletePOSKeyComponents(ctx context.Context, terminalID string) error {
+	query := `DELETE FROM payment_gateway.pos_key_components WHERE terminal_id = $1`
+	if _, err := r.db.ExecContext(ctx, query, terminalID); err != nil {
+		return fmt.Errorf("falha ao descartar componentes da TMK: %w", err)
+	}
+	return nil
+}
+
+// SavePOSAuthorization grava o desfecho da venda
+func (r *PostgresPOSStore) SavePOSAuthorization(ctx context.Context, authorization *POSAuthorization) error {
+	query := `
+		INSERT INTO payment_gateway.pos_authorizations (
+			transaction_id, tenant_id, terminal_id, network, stan, rrn, auth_code, response_code, approved,
+			offline, offline_reason, masked_pan, amount, currency, batch_number, authorized_at
+		) VALUES (
+			:transaction_id, :tenant_id, :terminal_id, :network, :stan, :rrn, :auth_code, :response_code, :approved,
+			:offline, :offline_reason, :masked_pan, :amount, :currency, :batch_number, :authorized_at
+		)
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			rrn = EXCLUDED.rrn,
+			auth_code = EXCLUDED.auth_code,
+			response_code = EXCLUDED.response_code,
+			approved = EXCLUDED.approved,
+			batch_number = EXCLUDED.batch_number
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, authorization); err != nil {
+		return fmt.Errorf("falha ao gravar autorização POS: %w", err)
+	}
+
+	return nil
+}
+
+// GetPOSAuthorization recupera o desfecho da venda
+func (r *PostgresPOSStore) GetPOSAuthorization(ctx context.Context, transactionID string) (*POSAuthorization, error) {
+	var authorization POSAuthorization
+	query := `SELECT transaction_id, tenant_id, terminal_id, network, stan, rrn, auth_code, response_code, approved,
+			offline, offline_reason, masked_pan, amount, currency, batch_number, authorized_at
+		FROM payment_gateway.pos_authorizations
+		WHERE transaction_id = $1`
+	if err := r.db.GetContext(ctx, &authorization, query, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPOSAuthorizationMissing
+		}
+		return nil, fmt.Errorf("falha ao recuperar autorização POS: %w", err)
+	}
+	return &authorization, nil
+}
+
+// SavePOSAdvice grava o aviso offline, substituindo o estado anterior
+func (r *PostgresPOSStore) SavePOSAdvice(ctx context.Context, advice *POSAdvice) error {
+	row := &dbPOSAdvice{POSAdvice: *advice}
+
+	var err error
+	if row.MessageJSON, err = json.Marshal(advice.Message); err != nil {
+		return fmt.Errorf("falha ao codificar mensagem do aviso: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.pos_advices (
+			id, terminal_id, transaction_id, network, amount, currency, reason, status, attempts,
+			response_code, last_error, created_at, forwarded_at, message
+		) VALUES (
+			:id, :terminal_id, :transaction_id, :network, :amount, :currency, :reason, :status, :attempts,
+			:response_code, :last_error, :created_at, :forwarded_at, :message
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			response_code = EXCLUDED.response_code,
+			last_error = EXCLUDED.last_error,
+			forwarded_at = EXCLUDED.forwarded_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar aviso POS: %w", err)
+	}
+
+	return nil
+}
+
+// ListPOSAdvices lista os avisos do filtro por ordem de aprovação
+func (r *PostgresPOSStore) ListPOSAdvices(ctx context.Context, filter POSAdviceFilter) ([]*POSAdvice, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", column, len(args)))
+	}
+	if filter.TerminalID != "" {
+		addCondition("terminal_id =", filter.TerminalID)
+	}
+	if filter.Status != "" {
+		addCondition("status =", filter.Status)
+	}
+
+	var rows []dbPOSAdvice
+	query := `SELECT ` + posAdviceColumns + ` FROM payment_gateway.pos_advices
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao listar avisos POS: %w", err)
+	}
+
+	advices := make([]*POSAdvice, 0, len(rows))
+	for i := range rows {
+		advice := rows[i].POSAdvice
+		if err := json.Unmarshal(rows[i].MessageJSON, &advice.Message); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar mensagem do aviso: %w", err)
+		}
+		advices = append(advices, &advice)
+	}
+	return advices, nil
+}
+
+// SavePOSBatch grava o lote, substituindo o estado anterior
+func (r *PostgresPOSStore) SavePOSBatch(ctx context.Context, batch *POSBatch) error {
+	row := &dbPOSBatch{POSBatch: *batch}
+
+	var err error
+	if row.TotalsJSON, err = json.Marshal(batch.Totals); err != nil {
+		return fmt.Errorf("falha ao codificar totais do lote: %w", err)
+	}
+	if row.TerminalTotalsJSON, err = json.Marshal(batch.TerminalTotals); err != nil {
+		return fmt.Errorf("falha ao codificar totais do terminal: %w", err)
+	}
+	if row.EntriesJSON, err = json.Marshal(batch.Entries); err != nil {
+		return fmt.Errorf("falha ao codificar vendas do lote: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.pos_batches (
+			terminal_id, network, batch_number, status, totals, terminal_totals, entries, uploaded,
+			response_code, opened_at, closed_at
+		) VALUES (
+			:terminal_id, :network, :batch_number, :status, :totals, :terminal_totals, :entries, :uploaded,
+			:response_code, :opened_at, :closed_at
+		)
+		ON CONFLICT (terminal_id, batch_number) DO UPDATE SET
+			status = EXCLUDED.status,
+			totals = EXCLUDED.totals,
+			terminal_totals = EXCLUDED.terminal_totals,
+			entries = EXCLUDED.entries,
+			uploaded = EXCLUDED.uploaded,
+			response_code = EXCLUDED.response_code,
+			closed_at = EXCLUDED.closed_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar lote POS: %w", err)
+	}
+
+	return nil
+}
+
+// ListPOSBatches lista os lotes do terminal, do mais antigo ao mais recente
+func (r *PostgresPOSStore) ListPOSBatches(ctx context.Context, terminalID string) ([]*POSBatch, error) {
+	var rows []dbPOSBatch
+	query := `SELECT ` + posBatchColumns + ` FROM payment_gateway.pos_batches
+		WHERE terminal_id = $1
+		ORDER BY batch_number`
+	if err := r.db.SelectContext(ctx, &rows, query, terminalID); err != nil {
+		return nil, fmt.Errorf("falha ao listar lotes POS: %w", err)
+	}
+
+	batches := make([]*POSBatch, 0, len(rows))
+	for i := range rows {
+		batch, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+	return batches, nil
+}
+
+// decode descodifica os limites offline e os custódios gravados em JSONB
+func (row *dbPOSTerminal) decode() (*POSTerminal, error) {
+	terminal := row.POSTerminal
+	if err := json.Unmarshal(row.OfflineLimitsJSON, &terminal.OfflineLimits); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar limites offline: %w", err)
+	}
+	if err := json.Unmarshal(row.KeyCustodiansJSON, &terminal.KeyCustodians); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar custódios: %w", err)
+	}
+	return &terminal, nil
+}
+
+// decode descodifica os totais e as vendas gravados em JSONB
+func (row *dbPOSBatch) decode() (*POSBatch, error) {
+	batch := row.POSBatch
+	if err := json.Unmarshal(row.TotalsJSON, &batch.Totals); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar totais do lote: %w", err)
+	}
+	if err := json.Unmarshal(row.TerminalTotalsJSON, &batch.TerminalTotals); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar totais do terminal: %w", err)
+	}
+	if err := json.Unmarshal(row.EntriesJSON, &batch.Entries); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar vendas do lote: %w", err)
+	}
+	return &batch, nil
+}
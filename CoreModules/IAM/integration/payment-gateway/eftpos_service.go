@@ -0,0 +1,1298 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// POSService gere os terminais EFTPOS de Angola e Moçambique: registo, injeção da TMK em controlo
+// duplo e chaves de sessão, autorização ISO 8583 no host da rede (EMIS, SIMO), aprovação offline
+// dentro dos limites com encaminhamento posterior dos avisos e liquidação dos lotes
+type POSService struct {
+	config POSConfig
+	store  POSStore
+	vault  cipher.AEAD // Cifra das chaves dos terminais em repouso
+
+	hostsMutex sync.RWMutex
+	hosts      map[string]ISO8583Host // Por rede
+
+	// Serializa as alterações dos terminais, dos avisos e dos lotes nesta réplica
+	mutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewPOSService cria o serviço dos terminais EFTPOS. As redes com HostAddr são ligadas por TCP;
+// sem KeyEncryptionKey, as chaves dos terminais são cifradas com uma chave efémera
+func NewPOSService(config POSConfig, store POSStore) (*POSService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-pos",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	networks := DefaultPOSNetworks()
+	for market, network := range config.Networks {
+		defaults := networks[market]
+		if network.Network == "" {
+			network.Network = defaults.Network
+		}
+		if network.Currency == "" {
+			network.Currency = defaults.Currency
+		}
+		if network.CurrencyCode == "" {
+			network.CurrencyCode = defaults.CurrencyCode
+		}
+		if network.AcquirerID != "" && (len(network.AcquirerID) > 11 || !posDigits(network.AcquirerID)) {
+			return nil, fmt.Errorf("identificação do adquirente da rede %s inválida: até 11 dígitos", network.Network)
+		}
+		if network.ZPK != "" {
+			if zpk, err := hex.DecodeString(network.ZPK); err != nil || len(zpk) != 16 {
+				return nil, fmt.Errorf("ZPK da rede %s inválida: esperados 32 caracteres hexadecimais", network.Network)
+			}
+		}
+		networks[market] = network
+	}
+	config.Networks = networks
+
+	offlineLimits := DefaultPOSOfflineLimits()
+	for market, limits := range config.OfflineLimits {
+		offlineLimits[market] = limits
+	}
+	config.OfflineLimits = offlineLimits
+
+	if config.SessionKeyLifetime <= 0 {
+		config.SessionKeyLifetime = DefaultPOSSessionKeyLifetime
+	}
+	if config.AdviceInterval <= 0 {
+		config.AdviceInterval = DefaultPOSAdviceInterval
+	}
+
+	logger := obsAdapter.Logger()
+	kek := make([]byte, 32)
+	if config.KeyEncryptionKey != "" {
+		decoded, err := hex.DecodeString(config.KeyEncryptionKey)
+		if err != nil || len(decoded) != 32 {
+			return nil, errors.New("chave de cifra dos terminais inválida: esperados 64 caracteres hexadecimais (AES-256)")
+		}
+		kek = decoded
+	} else {
+		if _, err := rand.Read(kek); err != nil {
+			return nil, fmt.Errorf("falha ao gerar chave de cifra dos terminais: %w", err)
+		}
+		logger.Warn("Chave de cifra dos terminais POS não configurada, usando chave efémera")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	vault, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]ISO8583Host)
+	for _, network := range networks {
+		if network.HostAddr != "" {
+			hosts[network.Network] = NewTCPISO8583Host(network)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &POSService{
+		config:          config,
+		store:           store,
+		vault:           vault,
+		hosts:           hosts,
+		logger:          logger,
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetISO8583Host substitui a ligação ao host de autorização da rede
+func (s *POSService) SetISO8583Host(network string, host ISO8583Host) {
+	s.hostsMutex.Lock()
+	defer s.hostsMutex.Unlock()
+
+	s.hosts[network] = host
+}
+
+// Start inicia o encaminhamento periódico dos avisos das vendas aprovadas offline
+func (s *POSService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.AdviceInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ForwardAdvices(s.ctx, ""); err != nil {
+					s.logger.Error("Falha ao encaminhar avisos offline", "error", err.Error())
+				}
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Encaminhamento de avisos POS iniciado", "interval", s.config.AdviceInterval.String())
+}
+
+// Stop interrompe o encaminhamento periódico
+func (s *POSService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// RegisterTerminal regista um terminal na rede do mercado; o terminal só transaciona depois da
+// injeção da TMK e da troca das chaves de sessão
+func (s *POSService) RegisterTerminal(ctx context.Context, req POSTerminalRequest) (*POSTerminal, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.RegisterTerminal")
+	defer span.End()
+
+	network, exists := s.config.Networks[req.RegionCode]
+	if !exists {
+		return nil, fmt.Errorf("%w: mercado %s", ErrPOSNetworkNotConfigured, req.RegionCode)
+	}
+	if len(req.TerminalID) != 8 || !posAlphanumeric(req.TerminalID) {
+		return nil, fmt.Errorf("%w: terminal_id deve ter 8 caracteres alfanuméricos", ErrPOSTerminalInvalid)
+	}
+	if req.CardAcceptorID == "" || len(req.CardAcceptorID) > 15 || !posAlphanumeric(req.CardAcceptorID) {
+		return nil, fmt.Errorf("%w: card_acceptor_id deve ter até 15 caracteres alfanuméricos", ErrPOSTerminalInvalid)
+	}
+	if req.MerchantID == "" || req.SerialNumber == "" {
+		return nil, fmt.Errorf("%w: merchant_id e serial_number são obrigatórios", ErrPOSTerminalInvalid)
+	}
+
+	limits := s.config.OfflineLimits[req.RegionCode]
+	if req.OfflineLimits != nil {
+		custom := *req.OfflineLimits
+		if custom.FloorLimit < 0 || custom.CumulativeLimit < 0 || custom.MaxCount < 0 ||
+			custom.FloorLimit > limits.FloorLimit || custom.CumulativeLimit > limits.CumulativeLimit || custom.MaxCount > limits.MaxCount {
+			return nil, fmt.Errorf("%w: limites offline acima dos do mercado %s", ErrPOSTerminalInvalid, req.RegionCode)
+		}
+		limits = custom
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.store.GetPOSTerminal(ctx, req.TerminalID); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPOSTerminalExists, req.TerminalID)
+	} else if !errors.Is(err, ErrPOSTerminalNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	terminal := &POSTerminal{
+		TerminalID:     req.TerminalID,
+		TenantID:       req.TenantID,
+		MerchantID:     req.MerchantID,
+		CardAcceptorID: req.CardAcceptorID,
+		RegionCode:     req.RegionCode,
+		Network:        network.Network,
+		SerialNumber:   req.SerialNumber,
+		Model:          req.Model,
+		Location:       req.Location,
+		Status:         POSTerminalStatusPendingKeys,
+		OfflineLimits:  limits,
+		RegisteredBy:   req.RegisteredBy,
+		RegisteredAt:   now,
+		UpdatedAt:      now,
+	}
+	if err := s.store.SavePOSTerminal(ctx, terminal); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Terminal POS registado",
+		"terminal_id", terminal.TerminalID,
+		"merchant_id", terminal.MerchantID,
+		"network", terminal.Network,
+		"registered_by", terminal.RegisteredBy)
+	return redactPOSTerminal(terminal), nil
+}
+
+// SubmitKeyComponent recebe o componente da TMK de um custódio. Com os componentes de custódios
+// distintos exigidos, a TMK é composta (XOR), verificada pelo KCV e guardada cifrada; as chaves de
+// sessão anteriores deixam de ser válidas. Um KCV divergente descarta os componentes recebidos
+func (s *POSService) SubmitKeyComponent(ctx context.Context, terminalID string, component POSKeyComponent) (*POSKeyInjectionStatus, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.SubmitKeyComponent")
+	defer span.End()
+
+	status, err := s.submitKeyComponent(ctx, terminalID, component)
+	if errors.Is(err, ErrPOSKeyInjectionRejected) {
+		s.logger.WarnWithContext(ctx, "Componente da TMK recusado",
+			"terminal_id", terminalID,
+			"custodian", component.Custodian,
+			"error", err.Error())
+		s.metricsRecorder.CounterInc("payment_gateway_pos_key_injections", map[string]string{"outcome": "rejected"})
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if status.Completed {
+		s.logger.InfoWithContext(ctx, "TMK do terminal injetada em controlo duplo",
+			"terminal_id", terminalID,
+			"custodians", strings.Join(status.Custodians, ", "),
+			"kcv", status.Terminal.MasterKeyKCV)
+		s.metricsRecorder.CounterInc("payment_gateway_pos_key_injections", map[string]string{"outcome": "completed"})
+	}
+	return status, nil
+}
+
+// submitKeyComponent valida o componente e compõe a TMK quando os componentes exigidos estão presentes
+func (s *POSService) submitKeyComponent(ctx context.Context, terminalID string, component POSKeyComponent) (*POSKeyInjectionStatus, error) {
+	if component.Custodian == "" {
+		return nil, fmt.Errorf("%w: custódio não identificado", ErrPOSKeyInjectionRejected)
+	}
+	plain, err := hex.DecodeString(component.Component)
+	if err != nil || len(plain) != 16 {
+		return nil, fmt.Errorf("%w: componente deve ter 32 caracteres hexadecimais", ErrPOSKeyInjectionRejected)
+	}
+	if kcv, _ := posKCV(plain); !strings.EqualFold(kcv, component.KCV) {
+		return nil, fmt.Errorf("%w: KCV do componente divergente", ErrPOSKeyInjectionRejected)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if terminal.Status == POSTerminalStatusDecommissioned {
+		return nil, fmt.Errorf("%w: %s", ErrPOSTerminalNotActive, terminal.Status)
+	}
+
+	received, err := s.store.ListPOSKeyComponents(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, previous := range received {
+		if previous.Custodian == component.Custodian {
+			return nil, fmt.Errorf("%w: o custódio %s já introduziu um componente (controlo duplo)",
+				ErrPOSKeyInjectionRejected, component.Custodian)
+		}
+		if !strings.EqualFold(previous.MasterKCV, component.MasterKCV) {
+			if err := s.store.DeletePOSKeyComponents(ctx, terminalID); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: KCV da TMK divergente entre custódios; componentes descartados", ErrPOSKeyInjectionRejected)
+		}
+	}
+
+	status := &POSKeyInjectionStatus{
+		TerminalID:         terminalID,
+		ComponentsReceived: len(received) + 1,
+		ComponentsRequired: posKeyComponentsRequired,
+	}
+	for _, previous := range received {
+		status.Custodians = append(status.Custodians, previous.Custodian)
+	}
+	status.Custodians = append(status.Custodians, component.Custodian)
+
+	if status.ComponentsReceived < posKeyComponentsRequired {
+		sealed, err := s.seal(terminalID, "component", plain)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.store.SavePOSKeyComponent(ctx, &POSPendingKeyComponent{
+			TerminalID: terminalID,
+			Custodian:  component.Custodian,
+			MasterKCV:  strings.ToUpper(component.MasterKCV),
+			Component:  sealed,
+			ReceivedAt: s.now().UTC(),
+		}); err != nil {
+			return nil, err
+		}
+		status.Terminal = redactPOSTerminal(terminal)
+		return status, nil
+	}
+
+	// Compor a TMK e descartar os componentes por compor
+	masterKey := append([]byte(nil), plain...)
+	for _, previous := range received {
+		part, err := s.open(terminalID, "component", previous.Component)
+		if err != nil {
+			return nil, err
+		}
+		for i := range masterKey {
+			masterKey[i] ^= part[i]
+		}
+	}
+	if err := s.store.DeletePOSKeyComponents(ctx, terminalID); err != nil {
+		return nil, err
+	}
+	kcv, err := posKCV(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(kcv, component.MasterKCV) {
+		return nil, fmt.Errorf("%w: KCV da TMK composta divergente; componentes descartados", ErrPOSKeyInjectionRejected)
+	}
+	sealed, err := s.seal(terminalID, "tmk", masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	terminal.MasterKey = sealed
+	terminal.PINKey, terminal.MACKey = nil, nil
+	terminal.MasterKeyKCV = kcv
+	terminal.KeyCustodians = status.Custodians
+	terminal.KeyInjectedAt = &now
+	terminal.SessionKeysIssuedAt, terminal.SessionKeysExpireAt = nil, nil
+	terminal.UpdatedAt = now
+	if terminal.Status == POSTerminalStatusPendingKeys {
+		terminal.Status = POSTerminalStatusActive
+	}
+	if err := s.store.SavePOSTerminal(ctx, terminal); err != nil {
+		return nil, err
+	}
+	status.Completed = true
+	status.Terminal = redactPOSTerminal(terminal)
+	return status, nil
+}
+
+// ExchangeSessionKeys gera novas chaves de sessão TPK e TAK para o terminal, retornadas cifradas com
+// a TMK. O terminal é identificado também pelo número de série; sem a TMK, as chaves são inúteis
+func (s *POSService) ExchangeSessionKeys(ctx context.Context, terminalID, serialNumber string) (*POSSessionKeys, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.ExchangeSessionKeys")
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if errors.Is(err, ErrPOSTerminalNotFound) || err == nil && terminal.SerialNumber != serialNumber {
+		s.logger.WarnWithContext(ctx, "Troca de chaves de sessão recusada",
+			"terminal_id", terminalID,
+			"serial_number", serialNumber)
+		return nil, ErrPOSTerminalNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if terminal.Status != POSTerminalStatusActive {
+		return nil, fmt.Errorf("%w: %s", ErrPOSTerminalNotActive, terminal.Status)
+	}
+	masterKey, err := s.open(terminalID, "tmk", terminal.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &POSSessionKeys{TerminalID: terminalID}
+	var sealedPIN, sealedMAC []byte
+	for _, target := range []struct {
+		label  string
+		sealed *[]byte
+		under  *string
+		kcv    *string
+	}{
+		{"tpk", &sealedPIN, &keys.TPK, &keys.TPKKCV},
+		{"tak", &sealedMAC, &keys.TAK, &keys.TAKKCV},
+	} {
+		key, err := posGenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := posECB(masterKey, key, false)
+		if err != nil {
+			return nil, err
+		}
+		if *target.kcv, err = posKCV(key); err != nil {
+			return nil, err
+		}
+		if *target.sealed, err = s.seal(terminalID, target.label, key); err != nil {
+			return nil, err
+		}
+		*target.under = strings.ToUpper(hex.EncodeToString(encrypted))
+	}
+
+	now := s.now().UTC()
+	expiresAt := now.Add(s.config.SessionKeyLifetime)
+	terminal.PINKey, terminal.MACKey = sealedPIN, sealedMAC
+	terminal.SessionKeysIssuedAt = &now
+	terminal.SessionKeysExpireAt = &expiresAt
+	terminal.UpdatedAt = now
+	if err := s.store.SavePOSTerminal(ctx, terminal); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	keys.ExpiresAt = expiresAt
+
+	s.metricsRecorder.CounterInc("payment_gateway_pos_session_keys", map[string]string{"network": terminal.Network})
+	return keys, nil
+}
+
+// SetTerminalStatus suspende, reativa ou retira um terminal; um terminal retirado perde as chaves
+func (s *POSService) SetTerminalStatus(ctx context.Context, terminalID, status, operator string) (*POSTerminal, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case terminal.Status == POSTerminalStatusDecommissioned:
+		return nil, fmt.Errorf("%w: %s", ErrPOSTerminalNotActive, terminal.Status)
+	case status == POSTerminalStatusActive && len(terminal.MasterKey) == 0:
+		return nil, fmt.Errorf("%w: TMK não injetada", ErrPOSTerminalInvalid)
+	case status == POSTerminalStatusDecommissioned:
+		terminal.MasterKey, terminal.PINKey, terminal.MACKey = nil, nil, nil
+		terminal.SessionKeysExpireAt = nil
+		if err := s.store.DeletePOSKeyComponents(ctx, terminalID); err != nil {
+			return nil, err
+		}
+	case status != POSTerminalStatusActive && status != POSTerminalStatusSuspended:
+		return nil, fmt.Errorf("%w: estado %q", ErrPOSTerminalInvalid, status)
+	}
+	terminal.Status = status
+	terminal.UpdatedAt = s.now().UTC()
+	if err := s.store.SavePOSTerminal(ctx, terminal); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Estado do terminal POS alterado",
+		"terminal_id", terminalID,
+		"status", status,
+		"operator", operator)
+	return redactPOSTerminal(terminal), nil
+}
+
+// GetTerminal retorna o terminal sem as chaves
+func (s *POSService) GetTerminal(ctx context.Context, terminalID string) (*POSTerminal, error) {
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	return redactPOSTerminal(terminal), nil
+}
+
+// ListTerminals retorna os terminais do comerciante (todos quando vazio) sem as chaves
+func (s *POSService) ListTerminals(ctx context.Context, merchantID string) ([]*POSTerminal, error) {
+	terminals, err := s.store.ListPOSTerminals(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	for i, terminal := range terminals {
+		terminals[i] = redactPOSTerminal(terminal)
+	}
+	return terminals, nil
+}
+
+// VerifyMAC valida o MAC (ISO 9797-1 algoritmo 3) calculado pelo terminal com a TAK sobre a mensagem
+func (s *POSService) VerifyMAC(ctx context.Context, terminalID string, message []byte, mac string) error {
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return err
+	}
+	macKey, err := s.sessionKey(terminal, "tak", terminal.MACKey)
+	if err != nil {
+		return err
+	}
+
+	expected, err := posRetailMAC(macKey, message)
+	if err != nil {
+		return err
+	}
+	received, err := hex.DecodeString(mac)
+	if err != nil || !hmac.Equal(expected, received) {
+		s.logger.WarnWithContext(ctx, "Pedido do terminal com MAC inválido", "terminal_id", terminalID)
+		s.metricsRecorder.CounterInc("payment_gateway_pos_invalid_mac", map[string]string{"network": terminal.Network})
+		return ErrPOSInvalidMAC
+	}
+	return nil
+}
+
+// SaleRequest cria o pedido de pagamento EFTPOS de uma venda do terminal, processado pelo conector
+func (s *POSService) SaleRequest(ctx context.Context, terminalID string, sale POSSaleRequest) (*PaymentRequest, error) {
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionID := sale.TransactionID
+	if transactionID == "" {
+		transactionID = fmt.Sprintf("pos-%s", uuid.New().String())
+	}
+	details := map[string]interface{}{
+		"terminal_id": terminal.TerminalID,
+		"stan":        sale.STAN,
+		"card_number": sale.PAN,
+		"card_expiry": sale.Expiry,
+		"entry_mode":  sale.EntryMode,
+	}
+	if sale.PINBlock != "" {
+		details["pin_block"] = sale.PINBlock
+	}
+	if sale.OfflineAuthCode != "" {
+		details["offline_auth_code"] = sale.OfflineAuthCode
+	}
+	if !sale.LocalTime.IsZero() {
+		details["local_time"] = sale.LocalTime.Format(time.RFC3339)
+	}
+
+	// Cartão com PIN é autenticação de dois fatores do titular
+	mfaLevel := "low"
+	if sale.PINBlock != "" {
+		mfaLevel = "high"
+	}
+	return &PaymentRequest{
+		RequestID:      transactionID,
+		TransactionID:  transactionID,
+		UserID:         "pos:" + terminal.TerminalID,
+		TenantID:       terminal.TenantID,
+		RegionCode:     terminal.RegionCode,
+		Amount:         sale.Amount,
+		Currency:       sale.Currency,
+		PaymentMethod:  PaymentMethodEFTPOS,
+		MerchantID:     terminal.MerchantID,
+		PaymentDetails: details,
+		MFALevel:       mfaLevel,
+		Timestamp:      s.now().UTC(),
+	}, nil
+}
+
+// Authorize autoriza a venda no host da rede do terminal. Com o host indisponível, a venda é aprovada
+// offline dentro dos limites do terminal e o aviso fica guardado para encaminhamento; as vendas já
+// aprovadas offline pelo terminal seguem diretamente para os avisos. As recusas da rede retornam o
+// desfecho com POSDeclinedError
+func (s *POSService) Authorize(ctx context.Context, req *PaymentRequest) (*POSAuthorization, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.Authorize")
+	defer span.End()
+
+	authorization, err := s.authorize(ctx, req)
+	var declined *POSDeclinedError
+	switch {
+	case errors.As(err, &declined):
+		s.metricsRecorder.CounterInc("payment_gateway_pos_authorizations", map[string]string{
+			"network": authorization.Network,
+			"outcome": "declined",
+		})
+		return authorization, err
+	case err != nil:
+		span.RecordError(err)
+		s.logger.WarnWithContext(ctx, "Venda POS recusada",
+			"transaction_id", req.TransactionID,
+			"terminal_id", req.PaymentDetails["terminal_id"],
+			"error", err.Error())
+		s.metricsRecorder.CounterInc("payment_gateway_pos_authorizations", map[string]string{"outcome": "error"})
+		return nil, err
+	}
+
+	outcome := "approved"
+	if authorization.Offline {
+		outcome = authorization.OfflineReason
+		s.logger.InfoWithContext(ctx, "Venda POS aprovada offline, aviso por encaminhar à rede",
+			"transaction_id", authorization.TransactionID,
+			"terminal_id", authorization.TerminalID,
+			"network", authorization.Network,
+			"reason", authorization.OfflineReason)
+	}
+	s.metricsRecorder.CounterInc("payment_gateway_pos_authorizations", map[string]string{
+		"network": authorization.Network,
+		"outcome": outcome,
+	})
+	return authorization, nil
+}
+
+// authorize troca o pedido 0200 com a rede ou aprova a venda offline
+func (s *POSService) authorize(ctx context.Context, req *PaymentRequest) (*POSAuthorization, error) {
+	sale, err := posSaleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	terminal, err := s.store.GetPOSTerminal(ctx, sale.terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if terminal.MerchantID != req.MerchantID {
+		return nil, fmt.Errorf("%w: terminal %s não pertence ao comerciante %s", ErrPOSInvalidSale, terminal.TerminalID, req.MerchantID)
+	}
+	pinKey, err := s.sessionKey(terminal, "tpk", terminal.PINKey)
+	if err != nil {
+		return nil, err
+	}
+
+	network := s.config.Networks[terminal.RegionCode]
+	if req.Currency != network.Currency {
+		return nil, fmt.Errorf("%w: moeda %s não aceite na rede %s", ErrPOSInvalidSale, req.Currency, network.Network)
+	}
+
+	now := s.now().UTC()
+	local := sale.localTime
+	if local.IsZero() {
+		local = now
+	}
+	authorization := &POSAuthorization{
+		TransactionID: req.TransactionID,
+		TenantID:      terminal.TenantID,
+		TerminalID:    terminal.TerminalID,
+		Network:       network.Network,
+		STAN:          sale.stan,
+		RRN:           posRRN(now, sale.stan),
+		MaskedPAN:     posMaskPAN(sale.pan),
+		Amount:        roundAmount(req.Amount),
+		Currency:      req.Currency,
+		AuthorizedAt:  now,
+	}
+	request := NewISO8583Message(ISO8583MTIAuthorizationRequest).
+		Set(2, sale.pan).
+		Set(3, "000000").
+		Set(4, posMinorUnits(req.Amount)).
+		Set(7, now.Format("0102150405")).
+		Set(11, sale.stan).
+		Set(12, local.Format("150405")).
+		Set(13, local.Format("0102")).
+		Set(14, sale.expiry).
+		Set(22, sale.entryMode).
+		Set(32, network.AcquirerID).
+		Set(37, authorization.RRN).
+		Set(41, terminal.TerminalID).
+		Set(42, terminal.CardAcceptorID).
+		Set(49, network.CurrencyCode)
+
+	// Venda aprovada offline pelo terminal: apenas o aviso à rede
+	if sale.offlineAuthCode != "" {
+		authorization.AuthCode = sale.offlineAuthCode
+		return s.approveOffline(ctx, terminal, authorization, request, POSOfflineReasonTerminal)
+	}
+
+	if sale.pinBlock != "" {
+		pinBlock, err := posTranslatePINBlock(sale.pinBlock, pinKey, network.ZPK)
+		if err != nil {
+			return nil, err
+		}
+		request.Set(52, pinBlock)
+	}
+
+	response, err := s.exchange(ctx, network.Network, request)
+	if errors.Is(err, ErrPOSHostUnavailable) {
+		// PIN online só é verificado pelo emissor: sem host a venda é recusada
+		if sale.pinBlock != "" {
+			return nil, fmt.Errorf("%w: PIN online sem emissor disponível", err)
+		}
+		authorization.AuthCode = posOfflineAuthCode(authorization.RRN)
+		return s.approveOffline(ctx, terminal, authorization, request, POSOfflineReasonStandIn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.MTI != ISO8583MTIAuthorizationResponse || response.Get(11) != sale.stan || response.Get(41) != terminal.TerminalID {
+		return nil, fmt.Errorf("%w: resposta %s não corresponde ao pedido", ErrISO8583Invalid, response.MTI)
+	}
+
+	authorization.ResponseCode = response.Get(39)
+	if authorization.ResponseCode != ISO8583ResponseApproved {
+		if err := s.store.SavePOSAuthorization(ctx, authorization); err != nil {
+			return nil, err
+		}
+		return authorization, &POSDeclinedError{ResponseCode: authorization.ResponseCode}
+	}
+	authorization.Approved = true
+	authorization.AuthCode = response.Get(38)
+	if rrn := response.Get(37); rrn != "" {
+		authorization.RRN = rrn
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.record(ctx, authorization); err != nil {
+		return nil, err
+	}
+	return authorization, nil
+}
+
+// approveOffline aprova a venda sem o host da rede quando cabe nos limites offline do terminal e guarda
+// o aviso (0220) para encaminhamento
+func (s *POSService) approveOffline(ctx context.Context, terminal *POSTerminal, authorization *POSAuthorization, request *ISO8583Message, reason string) (*POSAuthorization, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending, err := s.store.ListPOSAdvices(ctx, POSAdviceFilter{TerminalID: terminal.TerminalID, Status: POSAdviceStatusPending})
+	if err != nil {
+		return nil, err
+	}
+	limits := terminal.OfflineLimits
+	pendingAmount := 0.0
+	for _, advice := range pending {
+		pendingAmount += advice.Amount
+	}
+	switch {
+	case authorization.Amount > limits.FloorLimit:
+		return nil, fmt.Errorf("%w: valor %.2f acima do limite por transação de %.2f",
+			ErrPOSOfflineLimitExceeded, authorization.Amount, limits.FloorLimit)
+	case pendingAmount+authorization.Amount > limits.CumulativeLimit:
+		return nil, fmt.Errorf("%w: acumulado por encaminhar de %.2f acima de %.2f",
+			ErrPOSOfflineLimitExceeded, pendingAmount+authorization.Amount, limits.CumulativeLimit)
+	case len(pending)+1 > limits.MaxCount:
+		return nil, fmt.Errorf("%w: %d transações por encaminhar (máximo %d)",
+			ErrPOSOfflineLimitExceeded, len(pending)+1, limits.MaxCount)
+	}
+
+	message := NewISO8583Message(ISO8583MTIAdvice)
+	for field, value := range request.Fields {
+		message.Set(field, value)
+	}
+	message.Set(38, authorization.AuthCode).Set(39, ISO8583ResponseApproved)
+
+	authorization.Approved = true
+	authorization.Offline = true
+	authorization.OfflineReason = reason
+	authorization.ResponseCode = ISO8583ResponseApproved
+	if err := s.store.SavePOSAdvice(ctx, &POSAdvice{
+		ID:            uuid.New().String(),
+		TerminalID:    terminal.TerminalID,
+		TransactionID: authorization.TransactionID,
+		Network:       authorization.Network,
+		Amount:        authorization.Amount,
+		Currency:      authorization.Currency,
+		Reason:        reason,
+		Status:        POSAdviceStatusPending,
+		CreatedAt:     authorization.AuthorizedAt,
+		Message:       message,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, authorization); err != nil {
+		return nil, err
+	}
+	return authorization, nil
+}
+
+// record inclui a venda aprovada no lote aberto do terminal; chamado com o mutex
+func (s *POSService) record(ctx context.Context, authorization *POSAuthorization) error {
+	batch, err := s.openBatch(ctx, authorization.TerminalID, authorization.Network)
+	if err != nil {
+		return err
+	}
+	batch.Entries = append(batch.Entries, POSBatchEntry{
+		TransactionID: authorization.TransactionID,
+		STAN:          authorization.STAN,
+		RRN:           authorization.RRN,
+		AuthCode:      authorization.AuthCode,
+		Amount:        authorization.Amount,
+		Offline:       authorization.Offline,
+	})
+	batch.Totals = posBatchTotals(batch.Entries)
+	if err := s.store.SavePOSBatch(ctx, batch); err != nil {
+		return err
+	}
+	authorization.BatchNumber = batch.BatchNumber
+	return s.store.SavePOSAuthorization(ctx, authorization)
+}
+
+// openBatch retorna o lote aberto do terminal, abrindo um novo quando o último está em fecho, liquidado
+// ou por conciliar; chamado com o mutex
+func (s *POSService) openBatch(ctx context.Context, terminalID, network string) (*POSBatch, error) {
+	batches, err := s.store.ListPOSBatches(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) > 0 && batches[len(batches)-1].Status == POSBatchStatusOpen {
+		return batches[len(batches)-1], nil
+	}
+	return &POSBatch{
+		TerminalID:  terminalID,
+		Network:     network,
+		BatchNumber: len(batches) + 1,
+		Status:      POSBatchStatusOpen,
+		Entries:     []POSBatchEntry{},
+		OpenedAt:    s.now().UTC(),
+	}, nil
+}
+
+// GetAuthorization retorna o desfecho da venda da transação
+func (s *POSService) GetAuthorization(ctx context.Context, transactionID string) (*POSAuthorization, error) {
+	return s.store.GetPOSAuthorization(ctx, transactionID)
+}
+
+// ForwardAdvices encaminha à rede os avisos offline pendentes do terminal (todos quando vazio), por
+// ordem de aprovação. Com o host de uma rede indisponível, os avisos seguintes dessa rede aguardam
+// pela próxima execução. Os avisos recusados pelo emissor saem dos totais do lote
+func (s *POSService) ForwardAdvices(ctx context.Context, terminalID string) ([]*POSAdvice, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.ForwardAdvices")
+	defer span.End()
+
+	pending, err := s.store.ListPOSAdvices(ctx, POSAdviceFilter{TerminalID: terminalID, Status: POSAdviceStatusPending})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	unavailable := make(map[string]bool)
+	results := make([]*POSAdvice, 0, len(pending))
+	for _, advice := range pending {
+		if unavailable[advice.Network] {
+			continue
+		}
+		response, err := s.exchange(ctx, advice.Network, advice.Message)
+		if err == nil && (response.MTI != ISO8583MTIAdviceResponse || response.Get(11) != advice.Message.Get(11)) {
+			err = fmt.Errorf("%w: resposta %s não corresponde ao aviso", ErrISO8583Invalid, response.MTI)
+		}
+
+		advice.Attempts++
+		if err != nil {
+			unavailable[advice.Network] = errors.Is(err, ErrPOSHostUnavailable) || errors.Is(err, ErrPOSNetworkNotConfigured)
+			advice.LastError = err.Error()
+		} else {
+			now := s.now().UTC()
+			advice.ForwardedAt = &now
+			advice.ResponseCode = response.Get(39)
+			advice.LastError = ""
+			advice.Status = POSAdviceStatusForwarded
+			if advice.ResponseCode != ISO8583ResponseApproved {
+				advice.Status = POSAdviceStatusRejected
+			}
+		}
+		if err := s.saveForwardedAdvice(ctx, advice); err != nil {
+			span.RecordError(err)
+			return results, err
+		}
+
+		switch advice.Status {
+		case POSAdviceStatusRejected:
+			s.logger.WarnWithContext(ctx, "Venda offline recusada pelo emissor; risco do comerciante",
+				"advice_id", advice.ID,
+				"transaction_id", advice.TransactionID,
+				"terminal_id", advice.TerminalID,
+				"response_code", advice.ResponseCode)
+		case POSAdviceStatusPending:
+			s.logger.WarnWithContext(ctx, "Aviso offline não encaminhado",
+				"advice_id", advice.ID,
+				"terminal_id", advice.TerminalID,
+				"attempts", advice.Attempts,
+				"error", advice.LastError)
+		}
+		s.metricsRecorder.CounterInc("payment_gateway_pos_advices", map[string]string{
+			"network": advice.Network,
+			"status":  advice.Status,
+		})
+		results = append(results, advice)
+	}
+	return results, nil
+}
+
+// saveForwardedAdvice grava o desfecho do aviso e retira do lote a venda recusada pelo emissor
+func (s *POSService) saveForwardedAdvice(ctx context.Context, advice *POSAdvice) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.store.SavePOSAdvice(ctx, advice); err != nil {
+		return err
+	}
+	if advice.Status != POSAdviceStatusRejected {
+		return nil
+	}
+
+	batches, err := s.store.ListPOSBatches(ctx, advice.TerminalID)
+	if err != nil {
+		return err
+	}
+	for _, batch := range batches {
+		if batch.Status == POSBatchStatusSettled {
+			continue
+		}
+		for i := range batch.Entries {
+			if batch.Entries[i].TransactionID == advice.TransactionID {
+				batch.Entries[i].Rejected = true
+				batch.Totals = posBatchTotals(batch.Entries)
+				if err := s.store.SavePOSBatch(ctx, batch); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ListAdvices retorna os avisos offline do filtro
+func (s *POSService) ListAdvices(ctx context.Context, filter POSAdviceFilter) ([]*POSAdvice, error) {
+	return s.store.ListPOSAdvices(ctx, filter)
+}
+
+// CloseBatch fecha o lote mais antigo por liquidar do terminal. Os avisos offline são encaminhados
+// primeiro; os totais indicados pelo terminal, quando presentes, devem coincidir com os do gateway. A
+// rede recebe os totais (0500) e, quando os recusa (95), o lote em detalhe (0320) seguido de novo fecho.
+// Os lotes com totais divergentes são retornados com ErrPOSBatchOutOfBalance
+func (s *POSService) CloseBatch(ctx context.Context, terminalID string, terminalTotals *POSBatchTotals) (*POSBatch, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "POSService.CloseBatch")
+	defer span.End()
+
+	if _, err := s.ForwardAdvices(ctx, terminalID); err != nil {
+		return nil, err
+	}
+
+	batch, previous, settlement, uploads, err := s.beginClose(ctx, terminalID, terminalTotals)
+	if err != nil {
+		if errors.Is(err, ErrPOSBatchOutOfBalance) {
+			s.recordBatchOutcome(ctx, batch, err)
+		}
+		return batch, err
+	}
+
+	responseCode, uploaded, settleErr := s.settle(ctx, batch.Network, settlement, uploads)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case settleErr != nil:
+		// O lote volta ao estado anterior ao fecho para nova tentativa
+		batch.Status = previous
+	case responseCode != ISO8583ResponseApproved:
+		batch.ResponseCode = responseCode
+		batch.Uploaded = uploaded
+		batch.Status = POSBatchStatusOutOfBalance
+		settleErr = fmt.Errorf("%w: rede respondeu %s ao fecho", ErrPOSBatchOutOfBalance, responseCode)
+	default:
+		now := s.now().UTC()
+		batch.ResponseCode = responseCode
+		batch.Uploaded = uploaded
+		batch.Status = POSBatchStatusSettled
+		batch.ClosedAt = &now
+	}
+	if err := s.store.SavePOSBatch(ctx, batch); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if settleErr != nil && batch.Status == previous {
+		span.RecordError(settleErr)
+		return batch, settleErr
+	}
+	s.recordBatchOutcome(ctx, batch, settleErr)
+	return batch, settleErr
+}
+
+// beginClose reserva o lote para o fecho e prepara as mensagens de fecho e de envio detalhado; retorna
+// também o estado do lote antes do fecho
+func (s *POSService) beginClose(ctx context.Context, terminalID string, terminalTotals *POSBatchTotals) (*POSBatch, string, *ISO8583Message, []*ISO8583Message, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	terminal, err := s.store.GetPOSTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	pending, err := s.store.ListPOSAdvices(ctx, POSAdviceFilter{TerminalID: terminalID, Status: POSAdviceStatusPending})
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	if len(pending) > 0 {
+		return nil, "", nil, nil, fmt.Errorf("%w: %d por encaminhar", ErrPOSAdvicesPending, len(pending))
+	}
+
+	batches, err := s.store.ListPOSBatches(ctx, terminalID)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+	var batch *POSBatch
+	for _, candidate := range batches {
+		if candidate.Status == POSBatchStatusOpen || candidate.Status == POSBatchStatusOutOfBalance {
+			batch = candidate
+			break
+		}
+	}
+	if batch == nil {
+		return nil, "", nil, nil, ErrPOSBatchNotFound
+	}
+	if terminalTotals != nil {
+		totals := *terminalTotals
+		batch.TerminalTotals = &totals
+		if totals.DebitCount != batch.Totals.DebitCount || roundAmount(totals.DebitAmount) != batch.Totals.DebitAmount {
+			batch.Status = POSBatchStatusOutOfBalance
+			if err := s.store.SavePOSBatch(ctx, batch); err != nil {
+				return nil, "", nil, nil, err
+			}
+			return batch, "", nil, nil, fmt.Errorf("%w: terminal %d/%.2f, gateway %d/%.2f", ErrPOSBatchOutOfBalance,
+				totals.DebitCount, totals.DebitAmount, batch.Totals.DebitCount, batch.Totals.DebitAmount)
+		}
+	}
+	previous := batch.Status
+	batch.Status = POSBatchStatusClosing
+	if err := s.store.SavePOSBatch(ctx, batch); err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	network := s.config.Networks[terminal.RegionCode]
+	settlement := NewISO8583Message(ISO8583MTIReconciliation).
+		Set(7, s.now().UTC().Format("0102150405")).
+		Set(11, fmt.Sprintf("%06d", batch.BatchNumber%1000000)).
+		Set(32, network.AcquirerID).
+		Set(41, terminal.TerminalID).
+		Set(42, terminal.CardAcceptorID).
+		Set(49, network.CurrencyCode).
+		Set(60, strconv.Itoa(batch.BatchNumber)).
+		Set(74, "0").
+		Set(76, strconv.Itoa(batch.Totals.DebitCount)).
+		Set(86, "0").
+		Set(88, posMinorUnits(batch.Totals.DebitAmount))
+
+	var uploads []*ISO8583Message
+	for _, entry := range batch.Entries {
+		if entry.Rejected {
+			continue
+		}
+		uploads = append(uploads, NewISO8583Message(ISO8583MTIBatchUpload).
+			Set(3, "000000").
+			Set(4, posMinorUnits(entry.Amount)).
+			Set(11, entry.STAN).
+			Set(37, entry.RRN).
+			Set(38, entry.AuthCode).
+			Set(41, terminal.TerminalID).
+			Set(42, terminal.CardAcceptorID).
+			Set(60, strconv.Itoa(batch.BatchNumber)))
+	}
+	return batch, previous, settlement, uploads, nil
+}
+
+// settle envia os totais do lote e, quando a rede os recusa por divergência, o lote em detalhe
+// seguido de novo fecho
+func (s *POSService) settle(ctx context.Context, network string, settlement *ISO8583Message, uploads []*ISO8583Message) (string, bool, error) {
+	response, err := s.exchange(ctx, network, settlement)
+	if err != nil {
+		return "", false, err
+	}
+	if response.Get(39) != ISO8583ResponseReconcileError {
+		return response.Get(39), false, nil
+	}
+
+	for _, upload := range uploads {
+		uploadResponse, err := s.exchange(ctx, network, upload)
+		if err != nil {
+			return "", true, err
+		}
+		if uploadResponse.Get(39) != ISO8583ResponseApproved {
+			return uploadResponse.Get(39), true, nil
+		}
+	}
+	response, err = s.exchange(ctx, network, settlement)
+	if err != nil {
+		return "", true, err
+	}
+	return response.Get(39), true, nil
+}
+
+// recordBatchOutcome regista o desfecho do fecho do lote
+func (s *POSService) recordBatchOutcome(ctx context.Context, batch *POSBatch, err error) {
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Lote do terminal com totais divergentes",
+			"terminal_id", batch.TerminalID,
+			"batch_number", batch.BatchNumber,
+			"error", err.Error())
+	} else {
+		s.logger.InfoWithContext(ctx, "Lote do terminal liquidado",
+			"terminal_id", batch.TerminalID,
+			"batch_number", batch.BatchNumber,
+			"debit_count", batch.Totals.DebitCount,
+			"debit_amount", batch.Totals.DebitAmount,
+			"uploaded", batch.Uploaded)
+		s.metricsRecorder.HistogramObserve("payment_gateway_pos_batch_amount", batch.Totals.DebitAmount, map[string]string{
+			"network": batch.Network,
+		})
+	}
+	s.metricsRecorder.CounterInc("payment_gateway_pos_batches", map[string]string{
+		"network": batch.Network,
+		"status":  batch.Status,
+	})
+}
+
+// ListBatches retorna os lotes do terminal, do mais recente para o mais antigo
+func (s *POSService) ListBatches(ctx context.Context, terminalID string) ([]*POSBatch, error) {
+	batches, err := s.store.ListPOSBatches(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(batches)-1; i < j; i, j = i+1, j-1 {
+		batches[i], batches[j] = batches[j], batches[i]
+	}
+	return batches, nil
+}
+
+// exchange troca a mensagem com o host da rede
+func (s *POSService) exchange(ctx context.Context, network string, request *ISO8583Message) (*ISO8583Message, error) {
+	s.hostsMutex.RLock()
+	host, exists := s.hosts[network]
+	s.hostsMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s sem host de autorização", ErrPOSNetworkNotConfigured, network)
+	}
+	return host.Exchange(ctx, request)
+}
+
+// sessionKey verifica o estado do terminal e a validade das chaves de sessão e retorna a chave em claro
+func (s *POSService) sessionKey(terminal *POSTerminal, label string, sealed []byte) ([]byte, error) {
+	if terminal.Status != POSTerminalStatusActive {
+		return nil, fmt.Errorf("%w: %s", ErrPOSTerminalNotActive, terminal.Status)
+	}
+	if len(sealed) == 0 || terminal.SessionKeysExpireAt == nil || !s.now().Before(*terminal.SessionKeysExpireAt) {
+		return nil, ErrPOSSessionKeysExpired
+	}
+	return s.open(terminal.TerminalID, label, sealed)
+}
+
+// seal cifra uma chave do terminal com a chave de cifra do gateway, vinculada ao terminal e ao tipo
+func (s *POSService) seal(terminalID, label string, key []byte) ([]byte, error) {
+	nonce := make([]byte, s.vault.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.vault.Seal(nonce, nonce, key, []byte(terminalID+"/"+label)), nil
+}
+
+// open decifra uma chave do terminal guardada por seal
+func (s *POSService) open(terminalID, label string, sealed []byte) ([]byte, error) {
+	size := s.vault.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("%w: chave %s não injetada", ErrPOSSessionKeysExpired, label)
+	}
+	key, err := s.vault.Open(nil, sealed[:size], sealed[size:], []byte(terminalID+"/"+label))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao decifrar a chave %s do terminal %s", label, terminalID)
+	}
+	return key, nil
+}
+
+// redactPOSTerminal retira as chaves cifradas do terminal retornado fora do serviço
+func redactPOSTerminal(terminal *POSTerminal) *POSTerminal {
+	redacted := copyPOSTerminal(terminal)
+	redacted.MasterKey, redacted.PINKey, redacted.MACKey = nil, nil, nil
+	return redacted
+}
+
+// posBatchTotals soma as vendas do lote, excluindo as recusadas pelo emissor
+func posBatchTotals(entries []POSBatchEntry) POSBatchTotals {
+	var totals POSBatchTotals
+	for _, entry := range entries {
+		if !entry.Rejected {
+			totals.DebitCount++
+			totals.DebitAmount += entry.Amount
+		}
+	}
+	totals.DebitAmount = roundAmount(totals.DebitAmount)
+	return totals
+}
+
+// posSale são os dados de uma venda POS lidos de PaymentRequest.PaymentDetails
+type posSale struct {
+	terminalID      string
+	stan            string
+	pan             string
+	expiry          string
+	entryMode       string
+	pinBlock        string
+	localTime       time.Time
+	offlineAuthCode string
+}
+
+// posSaleFromRequest lê e valida os dados da venda POS em PaymentDetails
+func posSaleFromRequest(req *PaymentRequest) (*posSale, error) {
+	detail := func(key string) string {
+		value, _ := req.PaymentDetails[key].(string)
+		return value
+	}
+	sale := &posSale{
+		terminalID:      detail("terminal_id"),
+		stan:            detail("stan"),
+		pan:             detail("card_number"),
+		expiry:          detail("card_expiry"),
+		entryMode:       detail("entry_mode"),
+		pinBlock:        detail("pin_block"),
+		offlineAuthCode: detail("offline_auth_code"),
+	}
+	if local, err := time.Parse(time.RFC3339, detail("local_time")); err == nil {
+		sale.localTime = local
+	}
+
+	switch {
+	case sale.terminalID == "":
+		return nil, fmt.Errorf("%w: terminal_id ausente", ErrPOSInvalidSale)
+	case len(sale.stan) != 6 || !posDigits(sale.stan):
+		return nil, fmt.Errorf("%w: stan deve ter 6 dígitos", ErrPOSInvalidSale)
+	case len(sale.pan) < 12 || len(sale.pan) > 19 || !posDigits(sale.pan):
+		return nil, fmt.Errorf("%w: PAN inválido", ErrPOSInvalidSale)
+	case len(sale.expiry) != 4 || !posDigits(sale.expiry):
+		return nil, fmt.Errorf("%w: validade deve estar no formato AAMM", ErrPOSInvalidSale)
+	case len(sale.entryMode) != 3 || !posDigits(sale.entryMode):
+		return nil, fmt.Errorf("%w: entry_mode deve ter 3 dígitos", ErrPOSInvalidSale)
+	case req.Amount <= 0:
+		return nil, fmt.Errorf("%w: valor %.2f", ErrPOSInvalidSale, req.Amount)
+	}
+	return sale, nil
+}
+
+// posMinorUnits converte o valor para unidades menores da moeda (2 casas decimais em AOA e MZN)
+func posMinorUnits(amount float64) string {
+	return strconv.FormatInt(int64(math.Round(amount*100)), 10)
+}
+
+// posRRN gera o RRN (campo 37): último dígito do ano, dia juliano, hora e STAN
+func posRRN(now time.Time, stan string) string {
+	return fmt.Sprintf("%d%03d%02d%s", now.Year()%10, now.YearDay(), now.Hour(), stan)
+}
+
+// posOfflineAuthCode gera o código de autorização das aprovações offline do gateway ("Y1" seguido de 4 dígitos)
+func posOfflineAuthCode(rrn string) string {
+	return "Y1" + rrn[len(rrn)-4:]
+}
+
+// posMaskPAN mascara o PAN, mantendo os 6 primeiros e os 4 últimos dígitos (PCI DSS)
+func posMaskPAN(pan string) string {
+	if len(pan) < 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// posAlphanumeric indica se o valor é composto apenas por letras e dígitos ASCII
+func posAlphanumeric(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,608 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPOSZPK = "0123456789ABCDEFFEDCBA9876543210"
+
+// fakeISO8583Host responde às mensagens do gateway como o host de autorização de uma rede
+type fakeISO8583Host struct {
+	mutex     sync.Mutex
+	available bool
+	respond   func(request *ISO8583Message) string // Código de resposta (campo 39)
+	received  []*ISO8583Message
+}
+
+func (h *fakeISO8583Host) Exchange(ctx context.Context, request *ISO8583Message) (*ISO8583Message, error) {
+	// Mensagens trocadas pelo formato de transmissão
+	payload, err := request.Pack()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := UnpackISO8583(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if !h.available {
+		return nil, fmt.Errorf("%w: ligação recusada", ErrPOSHostUnavailable)
+	}
+	h.received = append(h.received, decoded)
+
+	code := ISO8583ResponseApproved
+	if h.respond != nil {
+		code = h.respond(decoded)
+	}
+	mti := []byte(decoded.MTI)
+	mti[2]++
+	response := NewISO8583Message(string(mti)).
+		Set(11, decoded.Get(11)).
+		Set(37, decoded.Get(37)).
+		Set(41, decoded.Get(41)).
+		Set(39, code)
+	if decoded.MTI == ISO8583MTIAuthorizationRequest && code == ISO8583ResponseApproved {
+		response.Set(38, "A"+decoded.Get(11)[1:])
+	}
+	return response, nil
+}
+
+func (h *fakeISO8583Host) setAvailable(available bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.available = available
+}
+
+func (h *fakeISO8583Host) messages(mti string) []*ISO8583Message {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var messages []*ISO8583Message
+	for _, message := range h.received {
+		if mti == "" || message.MTI == mti {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// newTestPOSService cria o serviço com os terminais de Angola ligados ao host EMIS simulado
+func newTestPOSService(t *testing.T, host *fakeISO8583Host) *POSService {
+	t.Helper()
+
+	service, err := NewPOSService(POSConfig{
+		Networks: map[string]POSNetworkConfig{
+			RegionAngola: {AcquirerID: "10001", ZPK: testPOSZPK},
+		},
+		OfflineLimits: map[string]POSOfflineLimits{
+			RegionAngola: {FloorLimit: 10000, CumulativeLimit: 15000, MaxCount: 2},
+		},
+	}, NewInMemoryPOSStore())
+	require.NoError(t, err)
+	service.SetISO8583Host(POSNetworkEMIS, host)
+	return service
+}
+
+// testPOSKeys são as chaves do terminal em claro, como ficam no equipamento
+type testPOSKeys struct {
+	tmk, tpk, tak []byte
+}
+
+// testPOSTerminalRequest cria o pedido de registo de um terminal do tenant de teste em Angola
+func testPOSTerminalRequest(terminalID string) POSTerminalRequest {
+	return POSTerminalRequest{
+		TerminalID:     terminalID,
+		TenantID:       "tenant-1",
+		MerchantID:     "merchant-1",
+		CardAcceptorID: "LOJA0001",
+		RegionCode:     RegionAngola,
+		SerialNumber:   "SN-" + terminalID,
+		RegisteredBy:   "ops-1",
+	}
+}
+
+// activateTestPOSTerminal regista o terminal, injeta a TMK por dois custódios e troca as chaves de sessão
+func activateTestPOSTerminal(t *testing.T, service *POSService, terminalID string) testPOSKeys {
+	t.Helper()
+	ctx := context.Background()
+	_, err := service.RegisterTerminal(ctx, testPOSTerminalRequest(terminalID))
+	require.NoError(t, err)
+
+	first, second := "0123456789ABCDEF0123456789ABCDEF", "FEDCBA9876543210F0E1D2C3B4A59687"
+	tmk := make([]byte, 16)
+	for _, component := range []string{first, second} {
+		part, _ := hex.DecodeString(component)
+		for i := range tmk {
+			tmk[i] ^= part[i]
+		}
+	}
+	masterKCV, err := posKCV(tmk)
+	require.NoError(t, err)
+	for custodian, component := range map[string]string{"custodio-a": first, "custodio-b": second} {
+		part, _ := hex.DecodeString(component)
+		kcv, err := posKCV(part)
+		require.NoError(t, err)
+		_, err = service.SubmitKeyComponent(ctx, terminalID, POSKeyComponent{
+			Component: component, KCV: kcv, MasterKCV: masterKCV, Custodian: custodian,
+		})
+		require.NoError(t, err)
+	}
+
+	sessionKeys, err := service.ExchangeSessionKeys(ctx, terminalID, "SN-"+terminalID)
+	require.NoError(t, err)
+	keys := testPOSKeys{tmk: tmk}
+	for target, encrypted := range map[*[]byte]string{&keys.tpk: sessionKeys.TPK, &keys.tak: sessionKeys.TAK} {
+		raw, _ := hex.DecodeString(encrypted)
+		*target, err = posECB(tmk, raw, true)
+		require.NoError(t, err)
+	}
+	return keys
+}
+
+// testPOSSale cria uma venda com chip sem PIN
+func testPOSSale(stan string, amount float64) POSSaleRequest {
+	return POSSaleRequest{
+		TransactionID: "pos-tx-" + stan,
+		STAN:          stan,
+		Amount:        amount,
+		Currency:      "AOA",
+		PAN:           "4111111111111111",
+		Expiry:        "2812",
+		EntryMode:     "051",
+	}
+}
+
+// testPINBlock cria o PIN block ISO 9564 formato 0 em claro
+func testPINBlock(pin, pan string) []byte {
+	pinField, _ := hex.DecodeString(fmt.Sprintf("%02d%s%s", len(pin), pin, strings.Repeat("F", 14-len(pin))))
+	panField, _ := hex.DecodeString("0000" + pan[len(pan)-13:len(pan)-1])
+	for i := range pinField {
+		pinField[i] ^= panField[i]
+	}
+	return pinField
+}
+
+// authorizeTestPOSSale autoriza a venda do terminal diretamente no serviço
+func authorizeTestPOSSale(t *testing.T, service *POSService, terminalID string, sale POSSaleRequest) (*POSAuthorization, error) {
+	t.Helper()
+	req, err := service.SaleRequest(context.Background(), terminalID, sale)
+	require.NoError(t, err)
+	return service.Authorize(context.Background(), req)
+}
+
+// newTestPOSRouter cria o router com a API dos terminais, processada pelo conector, e a API de suporte
+func newTestPOSRouter(t *testing.T, service *POSService) *mux.Router {
+	t.Helper()
+	connector, _ := newTestConnector(t)
+	connector.SetPOSService(service)
+
+	router := mux.NewRouter()
+	NewPOSTerminalHandler(service, connector).RegisterRoutes(router)
+	NewPOSHandler(service).RegisterRoutes(router)
+	return router
+}
+
+// servePOSTerminal envia um pedido do terminal autenticado com o MAC calculado com a TAK
+func servePOSTerminal(t *testing.T, router *mux.Router, tak []byte, terminalID, action string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	mac, err := posRetailMAC(tak, payload)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/pos/terminals/"+terminalID+"/"+action, strings.NewReader(string(payload)))
+	req.Header.Set(POSMACHeader, hex.EncodeToString(mac))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveSupport envia um pedido da API de suporte autenticado pelo operador do tenant de teste
+func serveSupport(router *mux.Router, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	req = req.WithContext(WithSupportPrincipal(req.Context(), &SupportPrincipal{Operator: "ops-1"}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestISO8583PackUnpack(t *testing.T) {
+	message := NewISO8583Message(ISO8583MTIReconciliation).
+		Set(11, "000007").
+		Set(32, "10001").
+		Set(41, "TERM0001").
+		Set(42, "LOJA0001").
+		Set(60, "7").
+		Set(76, "3").
+		Set(88, "1500000")
+	packed, err := message.Pack()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(packed), "050080"), "bitmap secundário com os totais: %s", packed)
+
+	unpacked, err := UnpackISO8583(packed)
+	require.NoError(t, err)
+	assert.Equal(t, ISO8583MTIReconciliation, unpacked.MTI)
+	assert.Equal(t, "10001", unpacked.Get(32))
+	assert.Equal(t, "LOJA0001", unpacked.Get(42), "espaços do campo alfanumérico removidos")
+	assert.Equal(t, "7", unpacked.Get(60))
+	assert.Equal(t, "0000000003", unpacked.Get(76))
+	assert.Equal(t, "0000000001500000", unpacked.Get(88))
+
+	tests := map[string]func() ([]byte, error){
+		"campo numérico com letras": func() ([]byte, error) { return NewISO8583Message("0200").Set(4, "12A").Pack() },
+		"campo acima do comprimento": func() ([]byte, error) {
+			return NewISO8583Message("0200").Set(41, "TERMINAL9").Pack()
+		},
+		"campo não suportado": func() ([]byte, error) { return NewISO8583Message("0200").Set(5, "1").Pack() },
+		"MTI inválido":        func() ([]byte, error) { return NewISO8583Message("02X0").Pack() },
+		"mensagem truncada": func() ([]byte, error) {
+			_, err := UnpackISO8583(packed[:len(packed)-3])
+			return nil, err
+		},
+		"bytes a mais": func() ([]byte, error) {
+			_, err := UnpackISO8583(append(append([]byte(nil), packed...), '0'))
+			return nil, err
+		},
+	}
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := run()
+			assert.ErrorIs(t, err, ErrISO8583Invalid)
+		})
+	}
+}
+
+func TestPOSKeyInjectionDualControl(t *testing.T) {
+	service := newTestPOSService(t, &fakeISO8583Host{available: true})
+	ctx := context.Background()
+
+	invalid := testPOSTerminalRequest("TERM01")
+	_, err := service.RegisterTerminal(ctx, invalid)
+	assert.ErrorIs(t, err, ErrPOSTerminalInvalid, "TID com 8 caracteres")
+	invalid = testPOSTerminalRequest("TERM0001")
+	invalid.OfflineLimits = &POSOfflineLimits{FloorLimit: 20000, CumulativeLimit: 15000, MaxCount: 2}
+	_, err = service.RegisterTerminal(ctx, invalid)
+	assert.ErrorIs(t, err, ErrPOSTerminalInvalid, "limites offline acima dos do mercado")
+	invalid = testPOSTerminalRequest("TERM0001")
+	invalid.RegionCode = RegionBrazil
+	_, err = service.RegisterTerminal(ctx, invalid)
+	assert.ErrorIs(t, err, ErrPOSNetworkNotConfigured)
+
+	terminal, err := service.RegisterTerminal(ctx, testPOSTerminalRequest("TERM0001"))
+	require.NoError(t, err)
+	assert.Equal(t, POSTerminalStatusPendingKeys, terminal.Status)
+	assert.Equal(t, POSNetworkEMIS, terminal.Network)
+	_, err = service.RegisterTerminal(ctx, testPOSTerminalRequest("TERM0001"))
+	assert.ErrorIs(t, err, ErrPOSTerminalExists)
+	_, err = service.ExchangeSessionKeys(ctx, "TERM0001", "SN-TERM0001")
+	assert.ErrorIs(t, err, ErrPOSTerminalNotActive, "sem TMK não há chaves de sessão")
+
+	first, second := "0123456789ABCDEF0123456789ABCDEF", "FEDCBA9876543210F0E1D2C3B4A59687"
+	firstKey, _ := hex.DecodeString(first)
+	secondKey, _ := hex.DecodeString(second)
+	tmk := make([]byte, 16)
+	for i := range tmk {
+		tmk[i] = firstKey[i] ^ secondKey[i]
+	}
+	firstKCV, _ := posKCV(firstKey)
+	secondKCV, _ := posKCV(secondKey)
+	masterKCV, _ := posKCV(tmk)
+
+	status, err := service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: first, KCV: firstKCV, MasterKCV: masterKCV, Custodian: "custodio-a"})
+	require.NoError(t, err)
+	assert.False(t, status.Completed)
+	assert.Equal(t, 1, status.ComponentsReceived)
+
+	// O mesmo custódio não introduz os dois componentes e o KCV de cada componente é verificado
+	_, err = service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: second, KCV: secondKCV, MasterKCV: masterKCV, Custodian: "custodio-a"})
+	assert.ErrorIs(t, err, ErrPOSKeyInjectionRejected)
+	_, err = service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: second, KCV: firstKCV, MasterKCV: masterKCV, Custodian: "custodio-b"})
+	assert.ErrorIs(t, err, ErrPOSKeyInjectionRejected)
+
+	status, err = service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: second, KCV: secondKCV, MasterKCV: masterKCV, Custodian: "custodio-b"})
+	require.NoError(t, err)
+	assert.True(t, status.Completed)
+	assert.Equal(t, POSTerminalStatusActive, status.Terminal.Status)
+	assert.Equal(t, masterKCV, status.Terminal.MasterKeyKCV)
+	assert.Equal(t, []string{"custodio-a", "custodio-b"}, status.Terminal.KeyCustodians)
+
+	_, err = service.ExchangeSessionKeys(ctx, "TERM0001", "SN-OUTRO")
+	assert.ErrorIs(t, err, ErrPOSTerminalNotFound, "número de série diferente do registado")
+
+	keys, err := service.ExchangeSessionKeys(ctx, "TERM0001", "SN-TERM0001")
+	require.NoError(t, err)
+	raw, _ := hex.DecodeString(keys.TAK)
+	tak, err := posECB(tmk, raw, true)
+	require.NoError(t, err)
+	takKCV, _ := posKCV(tak)
+	assert.Equal(t, keys.TAKKCV, takKCV, "TAK decifrada pelo terminal com a TMK")
+
+	body := []byte(`{"stan":"000001","amount":1500}`)
+	mac, err := posRetailMAC(tak, body)
+	require.NoError(t, err)
+	assert.NoError(t, service.VerifyMAC(ctx, "TERM0001", body, hex.EncodeToString(mac)))
+	assert.ErrorIs(t, service.VerifyMAC(ctx, "TERM0001", []byte(`{"stan":"000001","amount":9500}`), hex.EncodeToString(mac)), ErrPOSInvalidMAC)
+
+	// As chaves não saem do serviço, nem cifradas
+	terminals, err := service.ListTerminals(ctx, "merchant-1")
+	require.NoError(t, err)
+	require.Len(t, terminals, 1)
+	assert.Empty(t, terminals[0].MasterKey)
+	assert.Empty(t, terminals[0].MACKey)
+	encoded, err := json.Marshal(terminals)
+	require.NoError(t, err)
+	assert.NotContains(t, strings.ToUpper(string(encoded)), strings.ToUpper(hex.EncodeToString(tmk)))
+
+	// Um terminal retirado perde as chaves
+	_, err = service.SetTerminalStatus(ctx, "TERM0001", POSTerminalStatusDecommissioned, "ops-1")
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.VerifyMAC(ctx, "TERM0001", body, hex.EncodeToString(mac)), ErrPOSTerminalNotActive)
+}
+
+func TestPOSKeyInjectionDiscardsComponentsOnMasterKCVMismatch(t *testing.T) {
+	store := NewInMemoryPOSStore()
+	service, err := NewPOSService(POSConfig{}, store)
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = service.RegisterTerminal(ctx, testPOSTerminalRequest("TERM0001"))
+	require.NoError(t, err)
+
+	first, second := "0123456789ABCDEF0123456789ABCDEF", "FEDCBA9876543210F0E1D2C3B4A59687"
+	firstKey, _ := hex.DecodeString(first)
+	secondKey, _ := hex.DecodeString(second)
+	firstKCV, _ := posKCV(firstKey)
+	secondKCV, _ := posKCV(secondKey)
+
+	_, err = service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: first, KCV: firstKCV, MasterKCV: "AAAAAA", Custodian: "custodio-a"})
+	require.NoError(t, err)
+	pending, err := store.ListPOSKeyComponents(ctx, "TERM0001")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.NotContains(t, strings.ToUpper(hex.EncodeToString(pending[0].Component)), first, "componente cifrado em repouso")
+
+	_, err = service.SubmitKeyComponent(ctx, "TERM0001", POSKeyComponent{
+		Component: second, KCV: secondKCV, MasterKCV: "BBBBBB", Custodian: "custodio-b"})
+	assert.ErrorIs(t, err, ErrPOSKeyInjectionRejected)
+	pending, err = store.ListPOSKeyComponents(ctx, "TERM0001")
+	require.NoError(t, err)
+	assert.Empty(t, pending, "a cerimónia recomeça com os dois custódios")
+
+	terminal, err := service.GetTerminal(ctx, "TERM0001")
+	require.NoError(t, err)
+	assert.Equal(t, POSTerminalStatusPendingKeys, terminal.Status)
+}
+
+func TestPOSStoreAndForwardWithinOfflineLimits(t *testing.T) {
+	host := &fakeISO8583Host{available: true, respond: func(request *ISO8583Message) string {
+		if request.Get(11) == "000002" {
+			return ISO8583ResponseDeclined
+		}
+		return ISO8583ResponseApproved
+	}}
+	service := newTestPOSService(t, host)
+	keys := activateTestPOSTerminal(t, service, "TERM0001")
+	host.setAvailable(false)
+	ctx := context.Background()
+
+	// Host da rede indisponível: vendas sem PIN aprovadas offline dentro dos limites do terminal
+	authorization, err := authorizeTestPOSSale(t, service, "TERM0001", testPOSSale("000001", 8000))
+	require.NoError(t, err)
+	assert.True(t, authorization.Approved)
+	assert.True(t, authorization.Offline)
+	assert.Equal(t, POSOfflineReasonStandIn, authorization.OfflineReason)
+	assert.True(t, strings.HasPrefix(authorization.AuthCode, "Y1"))
+	assert.Equal(t, "411111******1111", authorization.MaskedPAN)
+
+	withPIN := testPOSSale("000003", 500)
+	encrypted, _ := posECB(keys.tpk, testPINBlock("1234", withPIN.PAN), false)
+	withPIN.PINBlock = hex.EncodeToString(encrypted)
+	_, err = authorizeTestPOSSale(t, service, "TERM0001", withPIN)
+	assert.ErrorIs(t, err, ErrPOSHostUnavailable, "PIN online exige o emissor")
+
+	_, err = authorizeTestPOSSale(t, service, "TERM0001", testPOSSale("000004", 12000))
+	assert.ErrorIs(t, err, ErrPOSOfflineLimitExceeded, "acima do limite por transação")
+
+	_, err = authorizeTestPOSSale(t, service, "TERM0001", testPOSSale("000002", 6000))
+	require.NoError(t, err)
+
+	// Venda aprovada pelo próprio terminal sem ligação: o número de avisos por encaminhar está no limite
+	uploaded := testPOSSale("000005", 100)
+	uploaded.OfflineAuthCode = "T00005"
+	_, err = authorizeTestPOSSale(t, service, "TERM0001", uploaded)
+	assert.ErrorIs(t, err, ErrPOSOfflineLimitExceeded)
+	pending, err := service.ListAdvices(ctx, POSAdviceFilter{TerminalID: "TERM0001", Status: POSAdviceStatusPending})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	// O lote só fecha depois de os avisos chegarem à rede
+	_, err = service.CloseBatch(ctx, "TERM0001", nil)
+	assert.ErrorIs(t, err, ErrPOSAdvicesPending)
+
+	host.setAvailable(true)
+	_, err = service.ForwardAdvices(ctx, "")
+	require.NoError(t, err)
+	advices, err := service.ListAdvices(ctx, POSAdviceFilter{TerminalID: "TERM0001"})
+	require.NoError(t, err)
+	require.Len(t, advices, 2)
+	assert.Equal(t, POSAdviceStatusForwarded, advices[0].Status)
+	assert.Equal(t, POSAdviceStatusRejected, advices[1].Status, "recusado pelo emissor")
+	assert.Equal(t, ISO8583ResponseDeclined, advices[1].ResponseCode)
+	forwarded := host.messages(ISO8583MTIAdvice)
+	require.Len(t, forwarded, 2)
+	assert.Equal(t, authorization.AuthCode, forwarded[0].Get(38))
+	assert.Equal(t, "800000", forwarded[0].Get(4)[6:])
+
+	batches, err := service.ListBatches(ctx, "TERM0001")
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, POSBatchTotals{DebitCount: 1, DebitAmount: 8000}, batches[0].Totals, "venda recusada fora dos totais")
+
+	// Com os avisos encaminhados, o terminal recupera os limites offline
+	uploaded.TransactionID = "pos-tx-000006"
+	authorization, err = authorizeTestPOSSale(t, service, "TERM0001", uploaded)
+	require.NoError(t, err)
+	assert.Equal(t, POSOfflineReasonTerminal, authorization.OfflineReason)
+	assert.Equal(t, "T00005", authorization.AuthCode)
+}
+
+func TestPOSBatchSettlementWithDetailUpload(t *testing.T) {
+	var settlements int
+	host := &fakeISO8583Host{available: true, respond: func(request *ISO8583Message) string {
+		switch {
+		case request.MTI == ISO8583MTIReconciliation:
+			settlements++
+			if settlements == 1 {
+				return ISO8583ResponseReconcileError
+			}
+		case request.Get(4) == "000000099900":
+			return ISO8583ResponseDeclined
+		}
+		return ISO8583ResponseApproved
+	}}
+	service := newTestPOSService(t, host)
+	keys := activateTestPOSTerminal(t, service, "TERM0001")
+	router := newTestPOSRouter(t, service)
+
+	rec := servePOSTerminal(t, router, keys.tak, "TERM0001", "sales", testPOSSale("000001", 2500))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var authorization POSAuthorization
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &authorization))
+	assert.True(t, authorization.Approved)
+	assert.False(t, authorization.Offline)
+	assert.Equal(t, "A00001", authorization.AuthCode)
+	assert.Len(t, authorization.RRN, 12)
+
+	// O PIN block chega à rede cifrado com a ZPK, não com a TPK do terminal
+	withPIN := testPOSSale("000002", 1500.50)
+	clearPIN := testPINBlock("4321", withPIN.PAN)
+	encrypted, _ := posECB(keys.tpk, clearPIN, false)
+	withPIN.PINBlock = hex.EncodeToString(encrypted)
+	rec = servePOSTerminal(t, router, keys.tak, "TERM0001", "sales", withPIN)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	requests := host.messages(ISO8583MTIAuthorizationRequest)
+	require.Len(t, requests, 2)
+	zpk, _ := hex.DecodeString(testPOSZPK)
+	received, _ := hex.DecodeString(requests[1].Get(52))
+	translated, err := posECB(zpk, received, true)
+	require.NoError(t, err)
+	assert.Equal(t, clearPIN, translated)
+	assert.Equal(t, "973", requests[1].Get(49))
+	assert.Equal(t, "LOJA0001", requests[1].Get(42))
+
+	// A recusa da rede é apresentada no terminal e fica fora do lote
+	rec = servePOSTerminal(t, router, keys.tak, "TERM0001", "sales", testPOSSale("000003", 999))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &authorization))
+	assert.False(t, authorization.Approved)
+	assert.Equal(t, ISO8583ResponseDeclined, authorization.ResponseCode)
+
+	// Pedido do terminal com MAC de outra mensagem
+	req := httptest.NewRequest(http.MethodPost, "/pos/terminals/TERM0001/sales", strings.NewReader(`{"stan":"000004"}`))
+	req.Header.Set(POSMACHeader, "0011223344556677")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Totais do terminal divergentes: o lote fica por conciliar sem chegar à rede
+	rec = servePOSTerminal(t, router, keys.tak, "TERM0001", "settlement", POSBatchTotals{DebitCount: 3, DebitAmount: 4999.50})
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	var batch POSBatch
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &batch))
+	assert.Equal(t, POSBatchStatusOutOfBalance, batch.Status)
+	assert.Equal(t, POSBatchTotals{DebitCount: 2, DebitAmount: 4000.50}, batch.Totals)
+	assert.Empty(t, host.messages(ISO8583MTIReconciliation))
+
+	// O suporte fecha com os totais do gateway; a rede recusa os totais (95) e recebe o lote em detalhe
+	rec = serveSupport(router, http.MethodPost, "/support/pos/terminals/TERM0001/batches/close")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &batch))
+	assert.Equal(t, POSBatchStatusSettled, batch.Status)
+	assert.True(t, batch.Uploaded)
+	assert.Equal(t, ISO8583ResponseApproved, batch.ResponseCode)
+
+	var flow []string
+	for _, message := range host.messages("") {
+		if message.MTI != ISO8583MTIAuthorizationRequest {
+			flow = append(flow, message.MTI)
+		}
+	}
+	assert.Equal(t, []string{"0500", "0320", "0320", "0500"}, flow)
+	totals := host.messages(ISO8583MTIReconciliation)[0]
+	assert.Equal(t, "0000000002", totals.Get(76))
+	assert.Equal(t, "0000000000400050", totals.Get(88))
+	assert.Equal(t, "1", totals.Get(60))
+
+	// A venda seguinte abre um novo lote
+	rec = servePOSTerminal(t, router, keys.tak, "TERM0001", "sales", testPOSSale("000005", 100))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &authorization))
+	assert.Equal(t, 2, authorization.BatchNumber)
+
+	// Os terminais de outro tenant não são visíveis no suporte
+	other := testPOSTerminalRequest("TERM0002")
+	other.TenantID = "tenant-2"
+	_, err = service.RegisterTerminal(context.Background(), other)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, serveSupport(router, http.MethodGet, "/support/pos/terminals/TERM0002").Code)
+}
+
+func TestProcessPaymentEFTPOS(t *testing.T) {
+	host := &fakeISO8583Host{available: true, respond: func(request *ISO8583Message) string {
+		if request.Get(11) == "000002" {
+			return ISO8583ResponseDeclined
+		}
+		return ISO8583ResponseApproved
+	}}
+	service := newTestPOSService(t, host)
+	activateTestPOSTerminal(t, service, "TERM0001")
+	connector, _ := newTestConnector(t)
+	connector.SetPOSService(service)
+	ctx := context.Background()
+
+	req, err := service.SaleRequest(ctx, "TERM0001", testPOSSale("000001", 2500))
+	require.NoError(t, err)
+	assert.Equal(t, PaymentMethodEFTPOS, req.PaymentMethod)
+	assert.Equal(t, "tenant-1", req.TenantID)
+	response, err := connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusApproved, response.Status)
+	assert.Equal(t, "A00001", response.ApprovalCode)
+	assert.Equal(t, false, response.Metadata["pos_offline"])
+	assert.Equal(t, POSNetworkEMIS, response.Metadata["pos_network"])
+
+	req, err = service.SaleRequest(ctx, "TERM0001", testPOSSale("000002", 2500))
+	require.NoError(t, err)
+	response, err = connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "pos_recusada", response.StatusCode)
+	assert.Empty(t, response.ApprovalCode)
+
+	// Acima do limite offline com o host indisponível
+	host.setAvailable(false)
+	req, err = service.SaleRequest(ctx, "TERM0001", testPOSSale("000003", 12000))
+	require.NoError(t, err)
+	response, err = connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "pos_limite_offline", response.StatusCode)
+}
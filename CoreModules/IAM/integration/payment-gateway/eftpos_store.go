@@ -0,0 +1,280 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// POSStore define a persistência dos terminais EFTPOS, das vendas, dos avisos offline e dos lotes
+type POSStore interface {
+	// SavePOSTerminal grava o terminal, substituindo o estado anterior
+	SavePOSTerminal(ctx context.Context, terminal *POSTerminal) error
+
+	// GetPOSTerminal recupera o terminal; retorna ErrPOSTerminalNotFound quando não existe
+	GetPOSTerminal(ctx context.Context, terminalID string) (*POSTerminal, error)
+
+	// ListPOSTerminals lista os terminais do comerciante (todos quando vazio) ordenados pelo TID
+	ListPOSTerminals(ctx context.Context, merchantID string) ([]*POSTerminal, error)
+
+	// SavePOSKeyComponent grava o componente da TMK de um custódio, substituindo o anterior do mesmo custódio
+	SavePOSKeyComponent(ctx context.Context, component *POSPendingKeyComponent) error
+
+	// ListPOSKeyComponents lista os componentes da TMK por compor do terminal, por ordem de receção
+	ListPOSKeyComponents(ctx context.Context, terminalID string) ([]*POSPendingKeyComponent, error)
+
+	// DeletePOSKeyComponents descarta os componentes da TMK por compor do terminal
+	DeletePOSKeyComponents(ctx context.Context, terminalID string) error
+
+	// SavePOSAuthorization grava o desfecho da venda
+	SavePOSAuthorization(ctx context.Context, authorization *POSAuthorization) error
+
+	// GetPOSAuthorization recupera o desfecho da venda; retorna ErrPOSAuthorizationMissing quando não existe
+	GetPOSAuthorization(ctx context.Context, transactionID string) (*POSAuthorization, error)
+
+	// SavePOSAdvice grava o aviso offline, substituindo o estado anterior
+	SavePOSAdvice(ctx context.Context, advice *POSAdvice) error
+
+	// ListPOSAdvices lista os avisos do filtro por ordem de aprovação
+	ListPOSAdvices(ctx context.Context, filter POSAdviceFilter) ([]*POSAdvice, error)
+
+	// SavePOSBatch grava o lote, substituindo o estado anterior
+	SavePOSBatch(ctx context.Context, batch *POSBatch) error
+
+	// ListPOSBatches lista os lotes do terminal, do mais antigo ao mais recente
+	ListPOSBatches(ctx context.Context, terminalID string) ([]*POSBatch, error)
+}
+
+// InMemoryPOSStore armazena os terminais e as vendas POS em memória
+type InMemoryPOSStore struct {
+	terminals      map[string]*POSTerminal
+	keyComponents  map[string][]*POSPendingKeyComponent
+	authorizations map[string]*POSAuthorization
+	advices        []*POSAdvice
+	batches        map[string][]*POSBatch
+	mutex          sync.RWMutex
+}
+
+// NewInMemoryPOSStore cria um novo armazenamento em memória
+func NewInMemoryPOSStore() *InMemoryPOSStore {
+	return &InMemoryPOSStore{
+		terminals:      make(map[string]*POSTerminal),
+		keyComponents:  make(map[string][]*POSPendingKeyComponent),
+		authorizations: make(map[string]*POSAuthorization),
+		batches:        make(map[string][]*POSBatch),
+	}
+}
+
+// SavePOSTerminal grava uma cópia do terminal
+func (s *InMemoryPOSStore) SavePOSTerminal(ctx context.Context, terminal *POSTerminal) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.terminals[terminal.TerminalID] = copyPOSTerminal(terminal)
+	return nil
+}
+
+// GetPOSTerminal retorna uma cópia do terminal
+func (s *InMemoryPOSStore) GetPOSTerminal(ctx context.Context, terminalID string) (*POSTerminal, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	terminal, ok := s.terminals[terminalID]
+	if !ok {
+		return nil, ErrPOSTerminalNotFound
+	}
+	return copyPOSTerminal(terminal), nil
+}
+
+// ListPOSTerminals lista cópias dos terminais do comerciante
+func (s *InMemoryPOSStore) ListPOSTerminals(ctx context.Context, merchantID string) ([]*POSTerminal, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	terminals := make([]*POSTerminal, 0)
+	for _, terminal := range s.terminals {
+		if merchantID == "" || terminal.MerchantID == merchantID {
+			terminals = append(terminals, copyPOSTerminal(terminal))
+		}
+	}
+	sort.Slice(terminals, func(i, j int) bool { return terminals[i].TerminalID < terminals[j].TerminalID })
+	return terminals, nil
+}
+
+// SavePOSKeyComponent grava uma cópia do componente
+func (s *InMemoryPOSStore) SavePOSKeyComponent(ctx context.Context, component *POSPendingKeyComponent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *component
+	copied.Component = append([]byte(nil), component.Component...)
+	components := s.keyComponents[component.TerminalID]
+	for i, existing := range components {
+		if existing.Custodian == component.Custodian {
+			components[i] = &copied
+			return nil
+		}
+	}
+	s.keyComponents[component.TerminalID] = append(components, &copied)
+	return nil
+}
+
+// ListPOSKeyComponents lista cópias dos componentes do terminal
+func (s *InMemoryPOSStore) ListPOSKeyComponents(ctx context.Context, terminalID string) ([]*POSPendingKeyComponent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	components := make([]*POSPendingKeyComponent, 0, len(s.keyComponents[terminalID]))
+	for _, component := range s.keyComponents[terminalID] {
+		copied := *component
+		copied.Component = append([]byte(nil), component.Component...)
+		components = append(components, &copied)
+	}
+	return components, nil
+}
+
+// DeletePOSKeyComponents descarta os componentes do terminal
+func (s *InMemoryPOSStore) DeletePOSKeyComponents(ctx context.Context, terminalID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.keyComponents, terminalID)
+	return nil
+}
+
+// SavePOSAuthorization grava uma cópia do desfecho da venda
+func (s *InMemoryPOSStore) SavePOSAuthorization(ctx context.Context, authorization *POSAuthorization) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *authorization
+	s.authorizations[authorization.TransactionID] = &copied
+	return nil
+}
+
+// GetPOSAuthorization retorna uma cópia do desfecho da venda
+func (s *InMemoryPOSStore) GetPOSAuthorization(ctx context.Context, transactionID string) (*POSAuthorization, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	authorization, ok := s.authorizations[transactionID]
+	if !ok {
+		return nil, ErrPOSAuthorizationMissing
+	}
+	copied := *authorization
+	return &copied, nil
+}
+
+// SavePOSAdvice grava uma cópia do aviso
+func (s *InMemoryPOSStore) SavePOSAdvice(ctx context.Context, advice *POSAdvice) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, existing := range s.advices {
+		if existing.ID == advice.ID {
+			s.advices[i] = copyPOSAdvice(advice)
+			return nil
+		}
+	}
+	s.advices = append(s.advices, copyPOSAdvice(advice))
+	return nil
+}
+
+// ListPOSAdvices lista cópias dos avisos do filtro
+func (s *InMemoryPOSStore) ListPOSAdvices(ctx context.Context, filter POSAdviceFilter) ([]*POSAdvice, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	advices := make([]*POSAdvice, 0)
+	for _, advice := range s.advices {
+		if (filter.TerminalID == "" || advice.TerminalID == filter.TerminalID) && (filter.Status == "" || advice.Status == filter.Status) {
+			advices = append(advices, copyPOSAdvice(advice))
+		}
+	}
+	return advices, nil
+}
+
+// SavePOSBatch grava uma cópia do lote
+func (s *InMemoryPOSStore) SavePOSBatch(ctx context.Context, batch *POSBatch) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	batches := s.batches[batch.TerminalID]
+	for i, existing := range batches {
+		if existing.BatchNumber == batch.BatchNumber {
+			batches[i] = copyPOSBatch(batch)
+			return nil
+		}
+	}
+	s.batches[batch.TerminalID] = append(batches, copyPOSBatch(batch))
+	sort.Slice(s.batches[batch.TerminalID], func(i, j int) bool {
+		return s.batches[batch.TerminalID][i].BatchNumber < s.batches[batch.TerminalID][j].BatchNumber
+	})
+	return nil
+}
+
+// ListPOSBatches lista cópias dos lotes do terminal
+func (s *InMemoryPOSStore) ListPOSBatches(ctx context.Context, terminalID string) ([]*POSBatch, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	batches := make([]*POSBatch, 0, len(s.batches[terminalID]))
+	for _, batch := range s.batches[terminalID] {
+		batches = append(batches, copyPOSBatch(batch))
+	}
+	return batches, nil
+}
+
+// copyPOSTerminal copia o terminal, os custódios, as datas e as chaves cifradas
+func copyPOSTerminal(terminal *POSTerminal) *POSTerminal {
+	copied := *terminal
+	copied.KeyCustodians = append([]string(nil), terminal.KeyCustodians...)
+	copied.MasterKey = append([]byte(nil), terminal.MasterKey...)
+	copied.PINKey = append([]byte(nil), terminal.PINKey...)
+	copied.MACKey = append([]byte(nil), terminal.MACKey...)
+	if terminal.KeyInjectedAt != nil {
+		at := *terminal.KeyInjectedAt
+		copied.KeyInjectedAt = &at
+	}
+	if terminal.SessionKeysIssuedAt != nil {
+		at := *terminal.SessionKeysIssuedAt
+		copied.SessionKeysIssuedAt = &at
+	}
+	if terminal.SessionKeysExpireAt != nil {
+		at := *terminal.SessionKeysExpireAt
+		copied.SessionKeysExpireAt = &at
+	}
+	return &copied
+}
+
+// copyPOSAdvice copia o aviso, a data de encaminhamento e a mensagem
+func copyPOSAdvice(advice *POSAdvice) *POSAdvice {
+	copied := *advice
+	if advice.ForwardedAt != nil {
+		forwardedAt := *advice.ForwardedAt
+		copied.ForwardedAt = &forwardedAt
+	}
+	if advice.Message != nil {
+		message := NewISO8583Message(advice.Message.MTI)
+		for field, value := range advice.Message.Fields {
+			message.Fields[field] = value
+		}
+		copied.Message = message
+	}
+	return &copied
+}
+
+// copyPOSBatch copia o lote, as vendas, os totais do terminal e a data de fecho
+func copyPOSBatch(batch *POSBatch) *POSBatch {
+	copied := *batch
+	copied.Entries = append([]POSBatchEntry(nil), batch.Entries...)
+	if batch.TerminalTotals != nil {
+		totals := *batch.TerminalTotals
+		copied.TerminalTotals = &totals
+	}
+	if batch.ClosedAt != nil {
+		closedAt := *batch.ClosedAt
+		copied.ClosedAt = &closedAt
+	}
+	return &copied
+}
@@ -0,0 +1,117 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// POSPaymentProcessor processa os pedidos de pagamento das vendas dos terminais
+type POSPaymentProcessor interface {
+	ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error)
+}
+
+// POSTerminalHandler expõe a API dos terminais EFTPOS. Os pedidos de venda e de fecho são
+// autenticados pelo MAC calculado com a TAK sobre o corpo, no cabeçalho X-POS-MAC; a troca das
+// chaves de sessão identifica o terminal pelo número de série em X-Terminal-Serial
+type POSTerminalHandler struct {
+	service   *POSService
+	processor POSPaymentProcessor
+}
+
+// NewPOSTerminalHandler cria uma nova instância do POSTerminalHandler; as vendas passam pelo
+// processador de pagamentos (BureauPaymentGatewayConnector)
+func NewPOSTerminalHandler(service *POSService, processor POSPaymentProcessor) *POSTerminalHandler {
+	return &POSTerminalHandler{service: service, processor: processor}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *POSTerminalHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/pos/terminals/{terminalId}/session-keys", h.ExchangeSessionKeys).Methods(http.MethodPost)
+	router.HandleFunc("/pos/terminals/{terminalId}/sales", h.Sale).Methods(http.MethodPost)
+	router.HandleFunc("/pos/terminals/{terminalId}/settlement", h.Settlement).Methods(http.MethodPost)
+}
+
+// ExchangeSessionKeys retorna novas chaves de sessão cifradas com a TMK do terminal
+func (h *POSTerminalHandler) ExchangeSessionKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ExchangeSessionKeys(r.Context(), mux.Vars(r)["terminalId"], r.Header.Get(POSSerialHeader))
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao trocar chaves de sessão")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// Sale processa a venda do terminal e responde com o desfecho a apresentar, incluindo as recusas
+// da rede; as vendas recusadas antes da rede respondem 422 com o código do pagamento
+func (h *POSTerminalHandler) Sale(w http.ResponseWriter, r *http.Request) {
+	terminalID := mux.Vars(r)["terminalId"]
+	body, ok := h.readAuthenticatedBody(w, r, terminalID)
+	if !ok {
+		return
+	}
+
+	var sale POSSaleRequest
+	if err := json.Unmarshal(body, &sale); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req, err := h.service.SaleRequest(r.Context(), terminalID, sale)
+	if err != nil {
+		respondWithPOSError(w, err, "Erro ao processar venda POS")
+		return
+	}
+
+	response, err := h.processor.ProcessPayment(r.Context(), req)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao processar venda POS")
+		return
+	}
+
+	// O desfecho da rede, aprovado ou recusado, fica guardado pelo serviço POS e é apresentado no terminal
+	if authorization, err := h.service.GetAuthorization(r.Context(), req.TransactionID); err == nil {
+		respondWithJSON(w, http.StatusOK, authorization)
+		return
+	}
+	if response.Status == TransactionStatusApproved {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Venda aprovada sem autorização POS")
+		return
+	}
+	respondWithError(w, http.StatusUnprocessableEntity, response.StatusCode, response.StatusDescription)
+}
+
+// Settlement fecha o lote com os totais indicados pelo terminal
+func (h *POSTerminalHandler) Settlement(w http.ResponseWriter, r *http.Request) {
+	terminalID := mux.Vars(r)["terminalId"]
+	body, ok := h.readAuthenticatedBody(w, r, terminalID)
+	if !ok {
+		return
+	}
+
+	var totals POSBatchTotals
+	if err := json.Unmarshal(body, &totals); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	batch, err := h.service.CloseBatch(r.Context(), terminalID, &totals)
+	respondWithPOSBatch(w, batch, err)
+}
+
+// readAuthenticatedBody lê o corpo do pedido e valida o MAC do terminal
+func (h *POSTerminalHandler) readAuthenticatedBody(w http.ResponseWriter, r *http.Request, terminalID string) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo do pedido inválido")
+		return nil, false
+	}
+	if err := h.service.VerifyMAC(r.Context(), terminalID, body, r.Header.Get(POSMACHeader)); err != nil {
+		respondWithPOSError(w, err, "Erro ao validar MAC do terminal")
+		return nil, false
+	}
+	return body, true
+}
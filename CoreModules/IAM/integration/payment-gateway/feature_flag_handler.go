@@ -0,0 +1,134 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FeatureFlagHandler expõe às equipas de suporte a matriz de feature flags e os níveis dos comerciantes
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type FeatureFlagHandler struct {
+	service *FeatureFlagService
+}
+
+// NewFeatureFlagHandler cria uma nova instância do FeatureFlagHandler
+func NewFeatureFlagHandler(service *FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{service: service}
+}
+
+// merchantTierRequest é o corpo da alteração do nível de um comerciante
+type merchantTierRequest struct {
+	Tier string `json:"tier"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *FeatureFlagHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/feature-flags", h.Status).Methods(http.MethodGet)
+	router.HandleFunc("/support/feature-flags/evaluate", h.Evaluate).Methods(http.MethodGet)
+	router.HandleFunc("/support/feature-flags/evaluations", h.Evaluations).Methods(http.MethodGet)
+	router.HandleFunc("/support/feature-flags/overrides", h.SetOverride).Methods(http.MethodPut)
+	router.HandleFunc("/support/feature-flags/overrides", h.RemoveOverride).Methods(http.MethodDelete)
+	router.HandleFunc("/support/feature-flags/reload", h.Reload).Methods(http.MethodPost)
+	router.HandleFunc("/support/merchants/{merchantId}/tier", h.GetMerchantTier).Methods(http.MethodGet)
+	router.HandleFunc("/support/merchants/{merchantId}/tier", h.SetMerchantTier).Methods(http.MethodPut)
+}
+
+// Status retorna as regras por origem e os níveis dos comerciantes
+func (h *FeatureFlagHandler) Status(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.Status())
+}
+
+// Evaluate retorna a decisão da matriz para um mercado, meio de pagamento e comerciante, sem a contabilizar
+func (h *FeatureFlagHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("market") == "" || query.Get("payment_method") == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "market e payment_method obrigatórios")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, h.service.Decide(query.Get("market"), query.Get("payment_method"), query.Get("merchant_id")))
+}
+
+// Evaluations retorna as avaliações por combinação, as com mais recusas primeiro
+func (h *FeatureFlagHandler) Evaluations(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.Evaluations())
+}
+
+// SetOverride ativa ou desativa uma célula da matriz em tempo de execução
+func (h *FeatureFlagHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	var rule FeatureFlagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	override, err := h.service.SetOverride(r.Context(), rule, supportOperator(r))
+	if err != nil {
+		h.respondWithFeatureFlagError(w, err, "Erro ao alterar feature flag")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, override)
+}
+
+// RemoveOverride remove a alteração em tempo de execução de uma célula da matriz
+func (h *FeatureFlagHandler) RemoveOverride(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	err := h.service.RemoveOverride(r.Context(), query.Get("market"), query.Get("payment_method"), query.Get("merchant_tier"), supportOperator(r))
+	if err != nil {
+		h.respondWithFeatureFlagError(w, err, "Erro ao remover alteração de feature flag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reload relê a matriz sem aguardar a releitura periódica
+func (h *FeatureFlagHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Reload(r.Context()); err != nil {
+		h.respondWithFeatureFlagError(w, err, "Erro ao reler matriz de feature flags")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, h.service.Status())
+}
+
+// GetMerchantTier retorna o nível do comerciante usado na matriz
+func (h *FeatureFlagHandler) GetMerchantTier(w http.ResponseWriter, r *http.Request) {
+	merchantID := mux.Vars(r)["merchantId"]
+	respondWithJSON(w, http.StatusOK, MerchantTierAssignment{MerchantID: merchantID, Tier: h.service.MerchantTier(merchantID)})
+}
+
+// SetMerchantTier altera o nível do comerciante em tempo de execução
+func (h *FeatureFlagHandler) SetMerchantTier(w http.ResponseWriter, r *http.Request) {
+	var req merchantTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	assignment, err := h.service.SetMerchantTier(r.Context(), mux.Vars(r)["merchantId"], req.Tier, supportOperator(r))
+	if err != nil {
+		h.respondWithFeatureFlagError(w, err, "Erro ao alterar nível do comerciante")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, assignment)
+}
+
+// respondWithFeatureFlagError traduz os erros da matriz de feature flags em respostas HTTP
+func (h *FeatureFlagHandler) respondWithFeatureFlagError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrFeatureFlagRuleInvalid), errors.Is(err, ErrFeatureFlagTierInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrFeatureFlagRuleNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrFeatureFlagSourceFailed):
+		respondWithError(w, http.StatusBadGateway, "source_unavailable", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
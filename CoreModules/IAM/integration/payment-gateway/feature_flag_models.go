@@ -0,0 +1,171 @@
+package paymentgateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Níveis de comerciante usados na matriz de feature flags
+const (
+	MerchantTierStandard   = "standard"
+	MerchantTierPremium    = "premium"
+	MerchantTierEnterprise = "enterprise"
+)
+
+// FeatureFlagAny corresponde, numa regra da matriz, a qualquer mercado, meio de pagamento ou nível de comerciante
+const FeatureFlagAny = "*"
+
+// Origens das regras da matriz de feature flags, da menor para a maior precedência
+const (
+	FeatureFlagSourceConfig   = "config"   // Regras da configuração do serviço
+	FeatureFlagSourceRemote   = "remote"   // Ficheiro ou serviço de feature flags em FeatureFlagConfig.Source
+	FeatureFlagSourceOverride = "override" // Alteração em tempo de execução pela API de suporte
+)
+
+// Valores padrão da matriz de feature flags
+const (
+	DefaultFeatureFlagRefreshInterval = 30 * time.Second // Releitura da origem remota e das alterações em tempo de execução
+	DefaultFeatureFlagFetchTimeout    = 5 * time.Second
+)
+
+// Erros da matriz de feature flags
+var (
+	ErrFeatureFlagRuleInvalid  = errors.New("regra de feature flag inválida")
+	ErrFeatureFlagRuleNotFound = errors.New("regra de feature flag não encontrada")
+	ErrFeatureFlagTierInvalid  = errors.New("nível de comerciante inválido")
+	ErrFeatureFlagSourceFailed = errors.New("falha ao ler origem das feature flags")
+)
+
+// FeatureFlagConfig contém configurações da matriz de feature flags
+type FeatureFlagConfig struct {
+	// Regras e níveis de comerciante da configuração, com a menor precedência
+	Rules         []FeatureFlagRule `json:"rules"`
+	MerchantTiers map[string]string `json:"merchant_tiers"`
+
+	// Ficheiro JSON ou URL do serviço de feature flags com um FeatureFlagDocument; vazio usa apenas Rules
+	Source          string            `json:"source"`
+	APIKey          string            `json:"-"`
+	RefreshInterval time.Duration     `json:"refresh_interval"`
+	HTTPTimeout     time.Duration     `json:"http_timeout"`
+	Resilience      resilience.Policy `json:"resilience"`
+}
+
+// FeatureFlagRule ativa ou desativa um meio de pagamento num mercado para um nível de comerciante;
+// FeatureFlagAny (ou vazio) em qualquer das dimensões aplica a regra a todos os valores
+type FeatureFlagRule struct {
+	Market        string    `json:"market" db:"market"`
+	PaymentMethod string    `json:"payment_method" db:"payment_method"`
+	MerchantTier  string    `json:"merchant_tier" db:"merchant_tier"`
+	Enabled       bool      `json:"enabled" db:"enabled"`
+	Reason        string    `json:"reason,omitempty" db:"reason"`
+	Source        string    `json:"source,omitempty" db:"-"`
+	UpdatedBy     string    `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt     time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// normalize preenche as dimensões vazias com FeatureFlagAny e valida o nível de comerciante
+func (r FeatureFlagRule) normalize() (FeatureFlagRule, error) {
+	for _, dimension := range []*string{&r.Market, &r.PaymentMethod, &r.MerchantTier} {
+		if *dimension = strings.TrimSpace(*dimension); *dimension == "" {
+			*dimension = FeatureFlagAny
+		}
+	}
+	if r.MerchantTier != FeatureFlagAny && !validMerchantTier(r.MerchantTier) {
+		return r, fmt.Errorf("%w: nível de comerciante %s desconhecido", ErrFeatureFlagRuleInvalid, r.MerchantTier)
+	}
+	return r, nil
+}
+
+// Key identifica a célula da matriz à qual a regra se aplica ("mercado/meio/nível")
+func (r FeatureFlagRule) Key() string {
+	return r.Market + "/" + r.PaymentMethod + "/" + r.MerchantTier
+}
+
+// matches indica se a regra se aplica à combinação de mercado, meio de pagamento e nível
+func (r FeatureFlagRule) matches(market, paymentMethod, tier string) bool {
+	return (r.Market == FeatureFlagAny || r.Market == market) &&
+		(r.PaymentMethod == FeatureFlagAny || r.PaymentMethod == paymentMethod) &&
+		(r.MerchantTier == FeatureFlagAny || r.MerchantTier == tier)
+}
+
+// specificity conta as dimensões da regra com valor concreto
+func (r FeatureFlagRule) specificity() int {
+	specificity := 0
+	for _, dimension := range []string{r.Market, r.PaymentMethod, r.MerchantTier} {
+		if dimension != FeatureFlagAny {
+			specificity++
+		}
+	}
+	return specificity
+}
+
+// validMerchantTier indica se o nível de comerciante é conhecido
+func validMerchantTier(tier string) bool {
+	switch tier {
+	case MerchantTierStandard, MerchantTierPremium, MerchantTierEnterprise:
+		return true
+	}
+	return false
+}
+
+// DefaultFeatureFlagRules retorna as restrições de mercado dos meios de pagamento locais: PIX e boleto
+// apenas no Brasil e mobile money (M-Pesa) apenas em Moçambique
+func DefaultFeatureFlagRules() []FeatureFlagRule {
+	return []FeatureFlagRule{
+		{Market: FeatureFlagAny, PaymentMethod: PaymentMethodPIX, MerchantTier: FeatureFlagAny, Enabled: false, Reason: "PIX apenas no Brasil"},
+		{Market: RegionBrazil, PaymentMethod: PaymentMethodPIX, MerchantTier: FeatureFlagAny, Enabled: true},
+		{Market: FeatureFlagAny, PaymentMethod: PaymentMethodBoleto, MerchantTier: FeatureFlagAny, Enabled: false, Reason: "Boleto apenas no Brasil"},
+		{Market: RegionBrazil, PaymentMethod: PaymentMethodBoleto, MerchantTier: FeatureFlagAny, Enabled: true},
+		{Market: FeatureFlagAny, PaymentMethod: PaymentMethodMobileMoney, MerchantTier: FeatureFlagAny, Enabled: false, Reason: "M-Pesa apenas em Moçambique"},
+		{Market: RegionMozambique, PaymentMethod: PaymentMethodMobileMoney, MerchantTier: FeatureFlagAny, Enabled: true},
+	}
+}
+
+// FeatureFlagDocument é o formato da matriz no ficheiro ou no serviço de feature flags
+type FeatureFlagDocument struct {
+	Version       string            `json:"version,omitempty"`
+	Rules         []FeatureFlagRule `json:"rules"`
+	MerchantTiers map[string]string `json:"merchant_tiers,omitempty"` // Nível por comerciante
+}
+
+// FeatureFlagDecision é o resultado da avaliação da matriz para um pagamento
+type FeatureFlagDecision struct {
+	Market        string           `json:"market"`
+	PaymentMethod string           `json:"payment_method"`
+	MerchantTier  string           `json:"merchant_tier"`
+	Enabled       bool             `json:"enabled"`
+	Rule          *FeatureFlagRule `json:"rule,omitempty"` // Regra decisiva; ausente quando nenhuma se aplica
+}
+
+// FeatureFlagEvaluationCount contabiliza as avaliações de uma combinação de mercado, meio e nível
+type FeatureFlagEvaluationCount struct {
+	Market        string    `json:"market"`
+	PaymentMethod string    `json:"payment_method"`
+	MerchantTier  string    `json:"merchant_tier"`
+	Enabled       int64     `json:"enabled"`
+	Disabled      int64     `json:"disabled"`
+	GatingRule    string    `json:"gating_rule,omitempty"` // Regra que recusou o pagamento mais recente ("origem:chave")
+	LastDisabled  time.Time `json:"last_disabled,omitempty"`
+}
+
+// FeatureFlagStatus descreve o estado da matriz para a API de suporte
+type FeatureFlagStatus struct {
+	Source        string            `json:"source,omitempty"`
+	Version       string            `json:"version,omitempty"`
+	LoadedAt      *time.Time        `json:"loaded_at,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+	Rules         []FeatureFlagRule `json:"rules"` // Por ordem de precedência das origens
+	MerchantTiers map[string]string `json:"merchant_tiers"`
+}
+
+// MerchantTierAssignment é o nível atribuído a um comerciante em tempo de execução
+type MerchantTierAssignment struct {
+	MerchantID string    `json:"merchant_id" db:"merchant_id"`
+	Tier       string    `json:"tier" db:"tier"`
+	UpdatedBy  string    `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
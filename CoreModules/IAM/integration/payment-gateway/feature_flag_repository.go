@@ -0,0 +1,108 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresFeatureFlagStore implementa FeatureFlagStore para PostgreSQL
+type PostgresFeatureFlagStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresFeatureFlagStore cria uma nova instância de PostgresFeatureFlagStore
+func NewPostgresFeatureFlagStore(db *sqlx.DB) *PostgresFeatureFlagStore {
+	return &PostgresFeatureFlagStore{db: db}
+}
+
+// SaveFeatureFlagOverride grava a alteração; uma nova alteração da célula substitui a anterior
+func (r *PostgresFeatureFlagStore) SaveFeatureFlagOverride(ctx context.Context, rule *FeatureFlagRule) error {
+	query := `
+		INSERT INTO payment_gateway.feature_flag_overrides (
+			market, payment_method, merchant_tier, enabled, reason, updated_by, updated_at
+		) VALUES (
+			:market, :payment_method, :merchant_tier, :enabled, :reason, :updated_by, :updated_at
+		)
+		ON CONFLICT (market, payment_method, merchant_tier) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			reason = EXCLUDED.reason,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, rule); err != nil {
+		return fmt.Errorf("falha ao gravar alteração de feature flag: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFeatureFlagOverride remove a alteração da célula
+func (r *PostgresFeatureFlagStore) DeleteFeatureFlagOverride(ctx context.Context, market, paymentMethod, merchantTier string) error {
+	query := `
+		DELETE FROM payment_gateway.feature_flag_overrides
+		WHERE market = $1 AND payment_method = $2 AND merchant_tier = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, market, paymentMethod, merchantTier)
+	if err != nil {
+		return fmt.Errorf("falha ao remover alteração de feature flag: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+	if affected == 0 {
+		return ErrFeatureFlagRuleNotFound
+	}
+
+	return nil
+}
+
+// ListFeatureFlagOverrides lista as alterações por célula
+func (r *PostgresFeatureFlagStore) ListFeatureFlagOverrides(ctx context.Context) ([]*FeatureFlagRule, error) {
+	var rules []*FeatureFlagRule
+	query := `
+		SELECT market, payment_method, merchant_tier, enabled, reason, updated_by, updated_at
+		FROM payment_gateway.feature_flag_overrides
+		ORDER BY market, payment_method, merchant_tier
+	`
+	if err := r.db.SelectContext(ctx, &rules, query); err != nil {
+		return nil, fmt.Errorf("falha ao listar alterações de feature flags: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveMerchantTier grava o nível do comerciante; um novo nível substitui o anterior
+func (r *PostgresFeatureFlagStore) SaveMerchantTier(ctx context.Context, assignment *MerchantTierAssignment) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_tiers (merchant_id, tier, updated_by, updated_at)
+		VALUES (:merchant_id, :tier, :updated_by, :updated_at)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			tier = EXCLUDED.tier,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, assignment); err != nil {
+		return fmt.Errorf("falha ao gravar nível do comerciante: %w", err)
+	}
+
+	return nil
+}
+
+// ListMerchantTiers lista os níveis atribuídos em tempo de execução
+func (r *PostgresFeatureFlagStore) ListMerchantTiers(ctx context.Context) ([]*MerchantTierAssignment, error) {
+	var assignments []*MerchantTierAssignment
+	query := `
+		SELECT merchant_id, tier, updated_by, updated_at
+		FROM payment_gateway.merchant_tiers
+		ORDER BY merchant_id
+	`
+	if err := r.db.SelectContext(ctx, &assignments, query); err != nil {
+		return nil, fmt.Errorf("falha ao listar níveis dos comerciantes: %w", err)
+	}
+	return assignments, nil
+}
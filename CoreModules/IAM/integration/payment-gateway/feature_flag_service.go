@@ -0,0 +1,429 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// FeatureFlagService decide que meios de pagamento estão disponíveis por mercado e nível de comerciante.
+// As regras vêm da configuração, de um ficheiro ou serviço de feature flags relido periodicamente e das
+// alterações feitas em tempo de execução pela API de suporte, gravadas no armazenamento e partilhadas
+// pelas réplicas. A origem de maior precedência com alguma regra aplicável decide; dentro da mesma
+// origem prevalece a regra mais específica e, em empate, a desativação. Sem regra aplicável o meio de
+// pagamento fica disponível
+type FeatureFlagService struct {
+	config FeatureFlagConfig
+	store  FeatureFlagStore
+	source FeatureFlagSource
+
+	mutex         sync.RWMutex
+	configRules   []FeatureFlagRule
+	remoteRules   []FeatureFlagRule
+	overrides     map[string]FeatureFlagRule
+	configTiers   map[string]string
+	remoteTiers   map[string]string
+	tierOverrides map[string]string
+	version       string
+	loadedAt      time.Time
+	lastError     string
+	evaluations   map[string]*FeatureFlagEvaluationCount
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewFeatureFlagService cria a matriz de feature flags; as regras e níveis inválidos da configuração
+// são ignorados. Sem origem indicada é usada a de config.Source
+func NewFeatureFlagService(config FeatureFlagConfig, store FeatureFlagStore, source FeatureFlagSource) (*FeatureFlagService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-feature-flags",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultFeatureFlagRefreshInterval
+	}
+	if source == nil {
+		source = NewFeatureFlagSource(config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &FeatureFlagService{
+		config:          config,
+		store:           store,
+		source:          source,
+		overrides:       make(map[string]FeatureFlagRule),
+		configTiers:     make(map[string]string),
+		remoteTiers:     make(map[string]string),
+		tierOverrides:   make(map[string]string),
+		evaluations:     make(map[string]*FeatureFlagEvaluationCount),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	service.configRules = service.validRules(config.Rules, FeatureFlagSourceConfig)
+	service.configTiers = service.validTiers(config.MerchantTiers)
+	return service, nil
+}
+
+// Start carrega a matriz e inicia a sua releitura periódica, que aplica sem novo deploy as alterações
+// da origem remota e as feitas noutras réplicas
+func (s *FeatureFlagService) Start() {
+	if err := s.Reload(s.ctx); err != nil {
+		s.logger.WarnWithContext(s.ctx, "Matriz de feature flags não carregada no arranque; regras da configuração em vigor",
+			"error", err.Error())
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Reload(s.ctx); err != nil {
+					s.logger.WarnWithContext(s.ctx, "Falha ao reler matriz de feature flags", "error", err.Error())
+				}
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Releitura da matriz de feature flags iniciada", "interval", s.config.RefreshInterval.String())
+}
+
+// Stop interrompe a releitura periódica
+func (s *FeatureFlagService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// validRules normaliza as regras e marca a sua origem, descartando as inválidas
+func (s *FeatureFlagService) validRules(rules []FeatureFlagRule, source string) []FeatureFlagRule {
+	valid := make([]FeatureFlagRule, 0, len(rules))
+	for _, rule := range rules {
+		normalized, err := rule.normalize()
+		if err != nil {
+			s.logger.Warn("Regra de feature flag ignorada", "rule", rule.Key(), "source", source, "error", err.Error())
+			continue
+		}
+		normalized.Source = source
+		valid = append(valid, normalized)
+	}
+	return valid
+}
+
+// validTiers descarta os níveis de comerciante desconhecidos
+func (s *FeatureFlagService) validTiers(tiers map[string]string) map[string]string {
+	valid := make(map[string]string, len(tiers))
+	for merchantID, tier := range tiers {
+		if !validMerchantTier(tier) {
+			s.logger.Warn("Nível de comerciante ignorado", "merchant_id", merchantID, "tier", tier)
+			continue
+		}
+		valid[merchantID] = tier
+	}
+	return valid
+}
+
+// Reload relê as alterações em tempo de execução do armazenamento e a matriz da origem remota. Uma
+// origem sem alterações mantém as regras atuais; em caso de erro as regras anteriores continuam em vigor
+func (s *FeatureFlagService) Reload(ctx context.Context) error {
+	ctx, span := s.tracer.StartSpan(ctx, "FeatureFlagService.Reload")
+	defer span.End()
+
+	overrides, err := s.store.ListFeatureFlagOverrides(ctx)
+	if err != nil {
+		span.RecordError(err)
+		s.recordReloadError(err)
+		return err
+	}
+	assignments, err := s.store.ListMerchantTiers(ctx)
+	if err != nil {
+		span.RecordError(err)
+		s.recordReloadError(err)
+		return err
+	}
+
+	var document *FeatureFlagDocument
+	var sourceErr error
+	if s.source != nil {
+		document, sourceErr = s.source.FetchFeatureFlags(ctx)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.overrides = make(map[string]FeatureFlagRule, len(overrides))
+	for _, rule := range overrides {
+		rule.Source = FeatureFlagSourceOverride
+		s.overrides[rule.Key()] = *rule
+	}
+	s.tierOverrides = make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		s.tierOverrides[assignment.MerchantID] = assignment.Tier
+	}
+
+	if sourceErr != nil {
+		span.RecordError(sourceErr)
+		s.lastError = sourceErr.Error()
+		return sourceErr
+	}
+	s.lastError = ""
+	if document != nil {
+		s.remoteRules = s.validRules(document.Rules, FeatureFlagSourceRemote)
+		s.remoteTiers = s.validTiers(document.MerchantTiers)
+		s.version = document.Version
+		s.loadedAt = s.now().UTC()
+		s.logger.InfoWithContext(ctx, "Matriz de feature flags carregada",
+			"source", s.config.Source,
+			"version", s.version,
+			"rules", len(s.remoteRules))
+	}
+	return nil
+}
+
+// recordReloadError regista a falha da última releitura no estado da matriz
+func (s *FeatureFlagService) recordReloadError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = err.Error()
+}
+
+// MerchantTier retorna o nível do comerciante: alteração em tempo de execução, origem remota,
+// configuração ou, na ausência de todas, MerchantTierStandard
+func (s *FeatureFlagService) MerchantTier(merchantID string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.merchantTierLocked(merchantID)
+}
+
+func (s *FeatureFlagService) merchantTierLocked(merchantID string) string {
+	for _, tiers := range []map[string]string{s.tierOverrides, s.remoteTiers, s.configTiers} {
+		if tier, exists := tiers[merchantID]; exists {
+			return tier
+		}
+	}
+	return MerchantTierStandard
+}
+
+// SetMerchantTier atribui o nível do comerciante em tempo de execução
+func (s *FeatureFlagService) SetMerchantTier(ctx context.Context, merchantID, tier, updatedBy string) (*MerchantTierAssignment, error) {
+	if !validMerchantTier(tier) {
+		return nil, fmt.Errorf("%w: %s", ErrFeatureFlagTierInvalid, tier)
+	}
+
+	assignment := &MerchantTierAssignment{MerchantID: merchantID, Tier: tier, UpdatedBy: updatedBy, UpdatedAt: s.now().UTC()}
+	if err := s.store.SaveMerchantTier(ctx, assignment); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.tierOverrides[merchantID] = tier
+	s.mutex.Unlock()
+
+	s.logger.InfoWithContext(ctx, "Nível do comerciante alterado",
+		"merchant_id", merchantID,
+		"tier", tier,
+		"updated_by", updatedBy)
+	return assignment, nil
+}
+
+// SetOverride cria ou substitui a alteração em tempo de execução de uma célula da matriz
+func (s *FeatureFlagService) SetOverride(ctx context.Context, rule FeatureFlagRule, updatedBy string) (*FeatureFlagRule, error) {
+	rule, err := rule.normalize()
+	if err != nil {
+		return nil, err
+	}
+	rule.Source = FeatureFlagSourceOverride
+	rule.UpdatedBy = updatedBy
+	rule.UpdatedAt = s.now().UTC()
+
+	if err := s.store.SaveFeatureFlagOverride(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.overrides[rule.Key()] = rule
+	s.mutex.Unlock()
+
+	s.logger.InfoWithContext(ctx, "Feature flag alterada em tempo de execução",
+		"rule", rule.Key(),
+		"enabled", rule.Enabled,
+		"reason", rule.Reason,
+		"updated_by", updatedBy)
+	return &rule, nil
+}
+
+// RemoveOverride remove a alteração em tempo de execução, repondo a decisão das outras origens
+func (s *FeatureFlagService) RemoveOverride(ctx context.Context, market, paymentMethod, merchantTier, removedBy string) error {
+	rule, err := FeatureFlagRule{Market: market, PaymentMethod: paymentMethod, MerchantTier: merchantTier}.normalize()
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteFeatureFlagOverride(ctx, rule.Market, rule.PaymentMethod, rule.MerchantTier); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	delete(s.overrides, rule.Key())
+	s.mutex.Unlock()
+
+	s.logger.InfoWithContext(ctx, "Alteração de feature flag removida",
+		"rule", rule.Key(),
+		"removed_by", removedBy)
+	return nil
+}
+
+// Decide avalia a matriz sem contabilizar a avaliação
+func (s *FeatureFlagService) Decide(market, paymentMethod, merchantID string) FeatureFlagDecision {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.decideLocked(market, paymentMethod, s.merchantTierLocked(merchantID))
+}
+
+func (s *FeatureFlagService) decideLocked(market, paymentMethod, tier string) FeatureFlagDecision {
+	decision := FeatureFlagDecision{Market: market, PaymentMethod: paymentMethod, MerchantTier: tier, Enabled: true}
+
+	overrides := make([]FeatureFlagRule, 0, len(s.overrides))
+	for _, rule := range s.overrides {
+		overrides = append(overrides, rule)
+	}
+	for _, rules := range [][]FeatureFlagRule{overrides, s.remoteRules, s.configRules} {
+		if rule := decisiveFeatureFlagRule(rules, market, paymentMethod, tier); rule != nil {
+			decision.Enabled = rule.Enabled
+			decision.Rule = rule
+			break
+		}
+	}
+	return decision
+}
+
+// decisiveFeatureFlagRule retorna a regra mais específica aplicável; em empate prevalece a desativação
+func decisiveFeatureFlagRule(rules []FeatureFlagRule, market, paymentMethod, tier string) *FeatureFlagRule {
+	var decisive *FeatureFlagRule
+	for i := range rules {
+		rule := rules[i]
+		if !rule.matches(market, paymentMethod, tier) {
+			continue
+		}
+		if decisive == nil || rule.specificity() > decisive.specificity() ||
+			(rule.specificity() == decisive.specificity() && !rule.Enabled && decisive.Enabled) {
+			decisive = &rule
+		}
+	}
+	return decisive
+}
+
+// Evaluate avalia a matriz para o pagamento, contabiliza a decisão e regista-a nas métricas
+func (s *FeatureFlagService) Evaluate(ctx context.Context, market, paymentMethod, merchantID string) FeatureFlagDecision {
+	s.mutex.Lock()
+	decision := s.decideLocked(market, paymentMethod, s.merchantTierLocked(merchantID))
+	key := market + "/" + paymentMethod + "/" + decision.MerchantTier
+	count, exists := s.evaluations[key]
+	if !exists {
+		count = &FeatureFlagEvaluationCount{Market: market, PaymentMethod: paymentMethod, MerchantTier: decision.MerchantTier}
+		s.evaluations[key] = count
+	}
+	result, gatingRule := "enabled", ""
+	if decision.Enabled {
+		count.Enabled++
+	} else {
+		result, gatingRule = "disabled", decision.Rule.Source+":"+decision.Rule.Key()
+		count.Disabled++
+		count.GatingRule = gatingRule
+		count.LastDisabled = s.now().UTC()
+	}
+	s.mutex.Unlock()
+
+	s.metricsRecorder.CounterInc("payment_gateway_feature_flag_evaluations", map[string]string{
+		"market":         market,
+		"payment_method": paymentMethod,
+		"merchant_tier":  decision.MerchantTier,
+		"result":         result,
+	})
+	if !decision.Enabled {
+		// Evento de segurança: o meio de pagamento foi recusado pela matriz
+		s.logger.WarnWithContext(ctx, "Meio de pagamento desativado por feature flag",
+			"market", market,
+			"payment_method", paymentMethod,
+			"merchant_id", merchantID,
+			"merchant_tier", decision.MerchantTier,
+			"rule", gatingRule)
+	}
+	return decision
+}
+
+// Evaluations retorna as contagens de avaliações, as combinações com mais recusas primeiro
+func (s *FeatureFlagService) Evaluations() []FeatureFlagEvaluationCount {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	counts := make([]FeatureFlagEvaluationCount, 0, len(s.evaluations))
+	for _, count := range s.evaluations {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Disabled != counts[j].Disabled {
+			return counts[i].Disabled > counts[j].Disabled
+		}
+		return counts[i].Market+"/"+counts[i].PaymentMethod+"/"+counts[i].MerchantTier <
+			counts[j].Market+"/"+counts[j].PaymentMethod+"/"+counts[j].MerchantTier
+	})
+	return counts
+}
+
+// Status retorna as regras de todas as origens, por precedência, e os níveis dos comerciantes
+func (s *FeatureFlagService) Status() FeatureFlagStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status := FeatureFlagStatus{
+		Source:        s.config.Source,
+		Version:       s.version,
+		LastError:     s.lastError,
+		Rules:         make([]FeatureFlagRule, 0, len(s.overrides)+len(s.remoteRules)+len(s.configRules)),
+		MerchantTiers: make(map[string]string),
+	}
+	if !s.loadedAt.IsZero() {
+		loadedAt := s.loadedAt
+		status.LoadedAt = &loadedAt
+	}
+
+	overrides := make([]FeatureFlagRule, 0, len(s.overrides))
+	for _, rule := range s.overrides {
+		overrides = append(overrides, rule)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Key() < overrides[j].Key() })
+	status.Rules = append(append(append(status.Rules, overrides...), s.remoteRules...), s.configRules...)
+
+	for _, tiers := range []map[string]string{s.configTiers, s.remoteTiers, s.tierOverrides} {
+		for merchantID, tier := range tiers {
+			status.MerchantTiers[merchantID] = tier
+		}
+	}
+	return status
+}
@@ -0,0 +1,256 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeatureFlagService(t *testing.T, config FeatureFlagConfig, store FeatureFlagStore) *FeatureFlagService {
+	t.Helper()
+
+	if store == nil {
+		store = NewInMemoryFeatureFlagStore()
+	}
+	service, err := NewFeatureFlagService(config, store, nil)
+	require.NoError(t, err)
+	service.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return service
+}
+
+func TestFeatureFlagPrecedence(t *testing.T) {
+	ctx := context.Background()
+	service := newTestFeatureFlagService(t, FeatureFlagConfig{
+		Rules: append(DefaultFeatureFlagRules(),
+			FeatureFlagRule{Market: RegionUSA, PaymentMethod: PaymentMethodDigitalWallet, Enabled: false},
+			FeatureFlagRule{Market: RegionUSA, PaymentMethod: PaymentMethodDigitalWallet, MerchantTier: MerchantTierEnterprise, Enabled: true},
+			FeatureFlagRule{Market: RegionEU, PaymentMethod: PaymentMethodSEPA, Enabled: true},
+			FeatureFlagRule{Market: RegionEU, PaymentMethod: PaymentMethodSEPA, Enabled: false, Reason: "empate"},
+			FeatureFlagRule{PaymentMethod: PaymentMethodCard, MerchantTier: "gold", Enabled: false},
+		),
+		MerchantTiers: map[string]string{"merchant-vip": MerchantTierEnterprise, "merchant-gold": "gold"},
+	}, nil)
+
+	tests := []struct {
+		name       string
+		market     string
+		method     string
+		merchantID string
+		enabled    bool
+		rule       string
+	}{
+		{"PIX no Brasil", RegionBrazil, PaymentMethodPIX, "merchant-1", true, RegionBrazil + "/pix/*"},
+		{"PIX fora do Brasil", RegionUSA, PaymentMethodPIX, "merchant-1", false, "*/pix/*"},
+		{"M-Pesa em Moçambique", RegionMozambique, PaymentMethodMobileMoney, "merchant-1", true, RegionMozambique + "/mobile_money/*"},
+		{"regra do mercado", RegionUSA, PaymentMethodDigitalWallet, "merchant-1", false, RegionUSA + "/digital_wallet/*"},
+		{"regra do nível mais específica", RegionUSA, PaymentMethodDigitalWallet, "merchant-vip", true, RegionUSA + "/digital_wallet/enterprise"},
+		{"empate prevalece a desativação", RegionEU, PaymentMethodSEPA, "merchant-1", false, RegionEU + "/sepa/*"},
+		{"nível desconhecido ignorado", RegionUSA, PaymentMethodCard, "merchant-gold", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := service.Decide(tt.market, tt.method, tt.merchantID)
+			assert.Equal(t, tt.enabled, decision.Enabled)
+			if tt.rule == "" {
+				assert.Nil(t, decision.Rule)
+				return
+			}
+			require.NotNil(t, decision.Rule)
+			assert.Equal(t, tt.rule, decision.Rule.Key())
+			assert.Equal(t, FeatureFlagSourceConfig, decision.Rule.Source)
+		})
+	}
+	assert.Equal(t, MerchantTierStandard, service.MerchantTier("merchant-gold"))
+
+	// A alteração em tempo de execução prevalece sobre a configuração, mesmo menos específica
+	_, err := service.SetOverride(ctx, FeatureFlagRule{PaymentMethod: PaymentMethodDigitalWallet, Enabled: false, Reason: "incidente"}, "agent-1")
+	require.NoError(t, err)
+	decision := service.Decide(RegionUSA, PaymentMethodDigitalWallet, "merchant-vip")
+	assert.False(t, decision.Enabled)
+	assert.Equal(t, FeatureFlagSourceOverride, decision.Rule.Source)
+	require.NoError(t, service.RemoveOverride(ctx, "", PaymentMethodDigitalWallet, "", "agent-1"))
+	assert.True(t, service.Decide(RegionUSA, PaymentMethodDigitalWallet, "merchant-vip").Enabled)
+	assert.ErrorIs(t, service.RemoveOverride(ctx, "", PaymentMethodDigitalWallet, "", "agent-1"), ErrFeatureFlagRuleNotFound)
+
+	_, err = service.SetOverride(ctx, FeatureFlagRule{PaymentMethod: PaymentMethodCard, MerchantTier: "gold"}, "agent-1")
+	assert.ErrorIs(t, err, ErrFeatureFlagRuleInvalid)
+	_, err = service.SetMerchantTier(ctx, "merchant-1", "gold", "agent-1")
+	assert.ErrorIs(t, err, ErrFeatureFlagTierInvalid)
+	_, err = service.SetMerchantTier(ctx, "merchant-1", MerchantTierEnterprise, "agent-1")
+	require.NoError(t, err)
+	assert.True(t, service.Decide(RegionUSA, PaymentMethodDigitalWallet, "merchant-1").Enabled)
+}
+
+func TestFeatureFlagOverridesSharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryFeatureFlagStore()
+	first := newTestFeatureFlagService(t, FeatureFlagConfig{}, store)
+	second := newTestFeatureFlagService(t, FeatureFlagConfig{}, store)
+
+	_, err := first.SetOverride(ctx, FeatureFlagRule{Market: RegionAngola, PaymentMethod: PaymentMethodCard, Enabled: false}, "agent-1")
+	require.NoError(t, err)
+	_, err = first.SetMerchantTier(ctx, "merchant-1", MerchantTierPremium, "agent-1")
+	require.NoError(t, err)
+	assert.True(t, second.Decide(RegionAngola, PaymentMethodCard, "merchant-1").Enabled)
+
+	// A réplica aplica as alterações na releitura seguinte
+	require.NoError(t, second.Reload(ctx))
+	decision := second.Decide(RegionAngola, PaymentMethodCard, "merchant-1")
+	assert.False(t, decision.Enabled)
+	assert.Equal(t, MerchantTierPremium, decision.MerchantTier)
+
+	require.NoError(t, first.RemoveOverride(ctx, RegionAngola, PaymentMethodCard, "*", "agent-1"))
+	require.NoError(t, second.Reload(ctx))
+	assert.True(t, second.Decide(RegionAngola, PaymentMethodCard, "merchant-1").Enabled)
+}
+
+func TestFeatureFlagRemoteSourceReload(t *testing.T) {
+	var mutex sync.Mutex
+	version := "v1"
+	document := FeatureFlagDocument{
+		Rules:         []FeatureFlagRule{{Market: RegionUSA, PaymentMethod: PaymentMethodBankTransfer, MerchantTier: MerchantTierStandard, Enabled: false}},
+		MerchantTiers: map[string]string{"merchant-2": MerchantTierPremium},
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+		if r.Header.Get("If-None-Match") == version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", version)
+		json.NewEncoder(w).Encode(document)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	service := newTestFeatureFlagService(t, FeatureFlagConfig{Source: server.URL}, nil)
+	require.NoError(t, service.Reload(ctx))
+	assert.False(t, service.Decide(RegionUSA, PaymentMethodBankTransfer, "merchant-1").Enabled)
+	assert.True(t, service.Decide(RegionUSA, PaymentMethodBankTransfer, "merchant-2").Enabled)
+	assert.Equal(t, "v1", service.Status().Version)
+
+	// Sem alterações no serviço a matriz mantém-se
+	require.NoError(t, service.Reload(ctx))
+	assert.False(t, service.Decide(RegionUSA, PaymentMethodBankTransfer, "merchant-1").Enabled)
+
+	// Nova versão publicada no serviço aplicada sem reiniciar o gateway
+	mutex.Lock()
+	version = "v2"
+	document.Rules[0].Enabled = true
+	mutex.Unlock()
+	require.NoError(t, service.Reload(ctx))
+	assert.True(t, service.Decide(RegionUSA, PaymentMethodBankTransfer, "merchant-1").Enabled)
+	assert.Equal(t, "v2", service.Status().Version)
+	assert.Equal(t, 3, requests)
+
+	// Falhas do serviço mantêm as regras anteriores
+	server.Close()
+	assert.ErrorIs(t, service.Reload(ctx), ErrFeatureFlagSourceFailed)
+	assert.True(t, service.Decide(RegionUSA, PaymentMethodBankTransfer, "merchant-1").Enabled)
+	assert.NotEmpty(t, service.Status().LastError)
+}
+
+func TestFeatureFlagFileSourceReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feature-flags.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": "2026-10", "rules": [
+		{"market": "BR", "payment_method": "pix", "merchant_tier": "*", "enabled": false, "reason": "manutenção do SPI"}]}`), 0o600))
+
+	ctx := context.Background()
+	service := newTestFeatureFlagService(t, FeatureFlagConfig{Rules: DefaultFeatureFlagRules(), Source: path}, nil)
+	require.NoError(t, service.Reload(ctx))
+	decision := service.Decide(RegionBrazil, PaymentMethodPIX, "merchant-1")
+	assert.False(t, decision.Enabled)
+	assert.Equal(t, FeatureFlagSourceRemote, decision.Rule.Source)
+	assert.Equal(t, "2026-10", service.Status().Version)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{not json`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.ErrorIs(t, service.Reload(ctx), ErrFeatureFlagSourceFailed)
+	assert.False(t, service.Decide(RegionBrazil, PaymentMethodPIX, "merchant-1").Enabled)
+}
+
+func TestProcessPaymentGatedByFeatureFlag(t *testing.T) {
+	ctx := context.Background()
+	connector, _ := newTestConnector(t)
+	featureFlags := newTestFeatureFlagService(t, FeatureFlagConfig{
+		Rules: []FeatureFlagRule{
+			{Market: RegionAngola, PaymentMethod: PaymentMethodCard, MerchantTier: MerchantTierStandard, Enabled: false, Reason: "cartões apenas para premium"},
+		},
+	}, nil)
+	connector.SetFeatureFlagService(featureFlags)
+
+	resp, err := connector.ProcessPayment(ctx, testRiskRequest(RegionAngola, "AOA", 1000))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusError, resp.Status)
+	assert.Equal(t, "meio_pagamento_desativado", resp.StatusCode)
+	assert.Contains(t, resp.StatusDescription, "cartões apenas para premium")
+
+	// Promover o comerciante pela API de suporte liberta o meio de pagamento sem reiniciar
+	router := mux.NewRouter()
+	NewFeatureFlagHandler(featureFlags).RegisterRoutes(router)
+	req := httptest.NewRequest(http.MethodPut, "/support/merchants/merchant-1/tier", strings.NewReader(`{"tier": "premium"}`))
+	req = req.WithContext(WithSupportPrincipal(req.Context(), &SupportPrincipal{Operator: "agent-1"}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var assignment MerchantTierAssignment
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &assignment))
+	assert.Equal(t, MerchantTierPremium, assignment.Tier)
+	assert.Equal(t, "agent-1", assignment.UpdatedBy)
+
+	resp, err = connector.ProcessPayment(ctx, testRiskRequest(RegionAngola, "AOA", 1000))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusApproved, resp.Status)
+
+	// Desativar cartões para todos os níveis em tempo de execução
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/support/feature-flags/overrides", strings.NewReader(
+		`{"market": "*", "payment_method": "card", "merchant_tier": "*", "enabled": false, "reason": "incidente no adquirente"}`)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	resp, err = connector.ProcessPayment(ctx, testRiskRequest(RegionAngola, "AOA", 1000))
+	require.NoError(t, err)
+	assert.Equal(t, "meio_pagamento_desativado", resp.StatusCode)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/feature-flags/evaluations", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var evaluations []FeatureFlagEvaluationCount
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &evaluations))
+	require.Len(t, evaluations, 2)
+	assert.Equal(t, MerchantTierPremium, evaluations[0].MerchantTier)
+	assert.Equal(t, int64(1), evaluations[0].Enabled)
+	assert.Equal(t, int64(1), evaluations[0].Disabled)
+	assert.Equal(t, "override:*/card/*", evaluations[0].GatingRule)
+	assert.Equal(t, int64(1), evaluations[1].Disabled)
+	assert.Equal(t, "config:"+RegionAngola+"/card/standard", evaluations[1].GatingRule)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/support/feature-flags/overrides?payment_method=card", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/support/feature-flags/overrides?payment_method=card", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/feature-flags/evaluate?market=AO&payment_method=card&merchant_id=merchant-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var decision FeatureFlagDecision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decision))
+	assert.True(t, decision.Enabled)
+}
@@ -0,0 +1,150 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// FeatureFlagSource lê a matriz de feature flags publicada fora do gateway
+type FeatureFlagSource interface {
+	// FetchFeatureFlags retorna o documento da matriz, com Version preenchida, ou nil quando não mudou
+	// desde a última leitura
+	FetchFeatureFlags(ctx context.Context) (*FeatureFlagDocument, error)
+}
+
+// NewFeatureFlagSource cria a origem indicada em config.Source: um URL http(s) do serviço de feature
+// flags ou o caminho de um ficheiro JSON; sem origem retorna nil
+func NewFeatureFlagSource(config FeatureFlagConfig) FeatureFlagSource {
+	source := strings.TrimSpace(config.Source)
+	switch {
+	case source == "":
+		return nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return NewHTTPFeatureFlagSource(config)
+	default:
+		return &FileFeatureFlagSource{path: source}
+	}
+}
+
+// FileFeatureFlagSource lê a matriz de um ficheiro JSON, relido apenas quando a data de modificação muda
+type FileFeatureFlagSource struct {
+	path    string
+	mutex   sync.Mutex
+	modTime time.Time
+}
+
+// FetchFeatureFlags lê o ficheiro; a versão, quando omitida, é a data de modificação
+func (s *FileFeatureFlagSource) FetchFeatureFlags(ctx context.Context) (*FeatureFlagDocument, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeatureFlagSourceFailed, err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil, nil
+	}
+	body, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeatureFlagSourceFailed, err)
+	}
+
+	document, err := decodeFeatureFlagDocument(body, info.ModTime().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	s.modTime = info.ModTime()
+	return document, nil
+}
+
+// HTTPFeatureFlagSource consulta o serviço de feature flags por HTTP/JSON com pedidos condicionais por ETag
+type HTTPFeatureFlagSource struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+	mutex      sync.Mutex
+	etag       string
+}
+
+// NewHTTPFeatureFlagSource cria o cliente do serviço de feature flags
+func NewHTTPFeatureFlagSource(config FeatureFlagConfig) *HTTPFeatureFlagSource {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultFeatureFlagFetchTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPFeatureFlagSource{
+		url:        strings.TrimSpace(config.Source),
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// FetchFeatureFlags consulta o serviço; 304 indica que a matriz não mudou e a versão, quando omitida, é o ETag
+func (s *HTTPFeatureFlagSource) FetchFeatureFlags(ctx context.Context) (*FeatureFlagDocument, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	resp, err := resilience.DoHTTP(ctx, s.httpClient, s.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+s.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeatureFlagSourceFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: serviço retornou status %d", ErrFeatureFlagSourceFailed, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeatureFlagSourceFailed, err)
+	}
+
+	etag := resp.Header.Get("ETag")
+	document, err := decodeFeatureFlagDocument(body, etag)
+	if err != nil {
+		return nil, err
+	}
+	s.etag = etag
+	return document, nil
+}
+
+// decodeFeatureFlagDocument descodifica a matriz e, sem versão no documento, usa a revisão da origem
+func decodeFeatureFlagDocument(body []byte, revision string) (*FeatureFlagDocument, error) {
+	var document FeatureFlagDocument
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, fmt.Errorf("%w: matriz inválida: %v", ErrFeatureFlagSourceFailed, err)
+	}
+	if document.Version == "" {
+		document.Version = revision
+	}
+	return &document, nil
+}
@@ -0,0 +1,102 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// FeatureFlagStore define a persistência das alterações em tempo de execução da matriz de feature flags,
+// partilhadas pelas réplicas do gateway
+type FeatureFlagStore interface {
+	// SaveFeatureFlagOverride cria ou substitui a alteração de uma célula da matriz
+	SaveFeatureFlagOverride(ctx context.Context, rule *FeatureFlagRule) error
+
+	// DeleteFeatureFlagOverride remove a alteração da célula; retorna ErrFeatureFlagRuleNotFound quando não existe
+	DeleteFeatureFlagOverride(ctx context.Context, market, paymentMethod, merchantTier string) error
+
+	// ListFeatureFlagOverrides lista as alterações por célula
+	ListFeatureFlagOverrides(ctx context.Context) ([]*FeatureFlagRule, error)
+
+	// SaveMerchantTier cria ou substitui o nível atribuído ao comerciante
+	SaveMerchantTier(ctx context.Context, assignment *MerchantTierAssignment) error
+
+	// ListMerchantTiers lista os níveis atribuídos em tempo de execução
+	ListMerchantTiers(ctx context.Context) ([]*MerchantTierAssignment, error)
+}
+
+// InMemoryFeatureFlagStore armazena as alterações da matriz de feature flags em memória
+type InMemoryFeatureFlagStore struct {
+	overrides map[string]*FeatureFlagRule
+	tiers     map[string]*MerchantTierAssignment
+	mutex     sync.RWMutex
+}
+
+// NewInMemoryFeatureFlagStore cria um novo armazenamento em memória
+func NewInMemoryFeatureFlagStore() *InMemoryFeatureFlagStore {
+	return &InMemoryFeatureFlagStore{
+		overrides: make(map[string]*FeatureFlagRule),
+		tiers:     make(map[string]*MerchantTierAssignment),
+	}
+}
+
+// SaveFeatureFlagOverride grava uma cópia da alteração
+func (s *InMemoryFeatureFlagStore) SaveFeatureFlagOverride(ctx context.Context, rule *FeatureFlagRule) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *rule
+	s.overrides[rule.Key()] = &copied
+	return nil
+}
+
+// DeleteFeatureFlagOverride remove a alteração da célula
+func (s *InMemoryFeatureFlagStore) DeleteFeatureFlagOverride(ctx context.Context, market, paymentMethod, merchantTier string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := FeatureFlagRule{Market: market, PaymentMethod: paymentMethod, MerchantTier: merchantTier}.Key()
+	if _, ok := s.overrides[key]; !ok {
+		return ErrFeatureFlagRuleNotFound
+	}
+	delete(s.overrides, key)
+	return nil
+}
+
+// ListFeatureFlagOverrides retorna cópias das alterações ordenadas por célula
+func (s *InMemoryFeatureFlagStore) ListFeatureFlagOverrides(ctx context.Context) ([]*FeatureFlagRule, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rules := make([]*FeatureFlagRule, 0, len(s.overrides))
+	for _, rule := range s.overrides {
+		copied := *rule
+		rules = append(rules, &copied)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Key() < rules[j].Key() })
+	return rules, nil
+}
+
+// SaveMerchantTier grava uma cópia do nível atribuído
+func (s *InMemoryFeatureFlagStore) SaveMerchantTier(ctx context.Context, assignment *MerchantTierAssignment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *assignment
+	s.tiers[assignment.MerchantID] = &copied
+	return nil
+}
+
+// ListMerchantTiers retorna cópias dos níveis atribuídos
+func (s *InMemoryFeatureFlagStore) ListMerchantTiers(ctx context.Context) ([]*MerchantTierAssignment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	assignments := make([]*MerchantTierAssignment, 0, len(s.tiers))
+	for _, assignment := range s.tiers {
+		copied := *assignment
+		assignments = append(assignments, &copied)
+	}
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].MerchantID < assignments[j].MerchantID })
+	return assignments, nil
+}
@@ -0,0 +1,102 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FraudFeedbackHandler expõe às equipas de suporte o registo dos rótulos de fraude confirmados
+// pelos emissores e comerciantes, o perfil de risco dos comerciantes e a auditoria dos seus ajustes
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type FraudFeedbackHandler struct {
+	service *FraudFeedbackService
+}
+
+// NewFraudFeedbackHandler cria uma nova instância do FraudFeedbackHandler
+func NewFraudFeedbackHandler(service *FraudFeedbackService) *FraudFeedbackHandler {
+	return &FraudFeedbackHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *FraudFeedbackHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/fraud-feedback", h.SubmitFeedback).Methods(http.MethodPost)
+	router.HandleFunc("/support/fraud-feedback", h.ListFeedback).Methods(http.MethodGet)
+	router.HandleFunc("/support/merchants/{merchantId}/risk-profile", h.GetMerchantRiskProfile).Methods(http.MethodGet)
+	router.HandleFunc("/support/merchants/{merchantId}/risk-adjustments", h.ListMerchantRiskAdjustments).Methods(http.MethodGet)
+}
+
+// SubmitFeedback regista o rótulo de uma transação com
+// {"transaction_id": "...", "label": "fraud|legit", "source": "issuer|merchant", "reason": "..."}
+func (h *FraudFeedbackHandler) SubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TransactionID string `json:"transaction_id"`
+		Label         string `json:"label"`
+		Source        string `json:"source"`
+		Reason        string `json:"reason"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil || body.TransactionID == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "transaction_id obrigatório")
+		return
+	}
+
+	result, err := h.service.Submit(r.Context(), FraudFeedback{
+		TenantID:      r.Header.Get("X-Tenant-ID"),
+		TransactionID: body.TransactionID,
+		Label:         body.Label,
+		Source:        body.Source,
+		Reason:        body.Reason,
+		ReportedBy:    supportOperator(r),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrFraudFeedbackInvalid):
+			respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		case errors.Is(err, ErrTransactionRecordNotFound):
+			respondWithError(w, http.StatusNotFound, "not_found", "Transação não avaliada pelo gateway")
+		case errors.Is(err, ErrFraudFeedbackSandbox):
+			respondWithError(w, http.StatusConflict, "sandbox_transaction", "Transações em sandbox não são rotuladas")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao registar rótulo de fraude")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ListFeedback lista os rótulos do tenant, filtrados por merchant_id quando indicado
+func (h *FraudFeedbackHandler) ListFeedback(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListFeedback(r.Context(), r.Header.Get("X-Tenant-ID"), r.URL.Query().Get("merchant_id"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar rótulos de fraude")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetMerchantRiskProfile retorna o perfil de risco do comerciante ajustado pelos rótulos
+func (h *FraudFeedbackHandler) GetMerchantRiskProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.service.GetMerchantRiskProfile(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao recuperar perfil de risco do comerciante")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// ListMerchantRiskAdjustments lista a auditoria dos ajustes automáticos do perfil do comerciante
+func (h *FraudFeedbackHandler) ListMerchantRiskAdjustments(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListMerchantRiskAdjustments(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar ajustes do perfil de risco do comerciante")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,161 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Rótulos confirmados das transações reportadas após o processamento
+const (
+	FraudLabelFraud = "fraud" // Fraude confirmada (inclui chargebacks por fraude)
+	FraudLabelLegit = "legit" // Transação legítima, incluindo alertas de fraude revertidos
+)
+
+// Origens do reporte de fraude
+const (
+	FraudFeedbackSourceIssuer   = "issuer"   // Emissor do meio de pagamento (ex.: TC40/SAFE, chargeback)
+	FraudFeedbackSourceMerchant = "merchant" // Comerciante após investigação própria
+)
+
+// Níveis do perfil de risco dos comerciantes ajustado pelos rótulos confirmados
+const (
+	MerchantRiskLow      = "low"
+	MerchantRiskElevated = "elevated"
+	MerchantRiskHigh     = "high"
+	MerchantRiskCritical = "critical"
+)
+
+// Valores padrão do ciclo de feedback de fraude
+const (
+	DefaultFraudVelocityWindow     = 24 * time.Hour // Período das contagens de velocidade
+	DefaultFraudScoringHookTimeout = 5 * time.Second
+
+	// Fraudes confirmadas antes de o nível do comerciante subir, para que um reporte isolado não o altere
+	merchantRiskMinFraudReports = 3
+)
+
+// merchantRiskScores é a contribuição de cada nível do perfil do comerciante para o score das suas transações
+var merchantRiskScores = map[string]float64{
+	MerchantRiskLow:      0,
+	MerchantRiskElevated: 0.3,
+	MerchantRiskHigh:     0.6,  // Verificação reforçada
+	MerchantRiskCritical: 0.85, // Rejeição
+}
+
+// Erros do ciclo de feedback de fraude
+var (
+	ErrFraudFeedbackNotFound       = errors.New("rótulo de fraude não encontrado para a transação")
+	ErrFraudFeedbackInvalid        = errors.New("rótulo de fraude inválido")
+	ErrFraudFeedbackSandbox        = errors.New("transações em sandbox não são rotuladas")
+	ErrMerchantRiskProfileNotFound = errors.New("perfil de risco do comerciante não encontrado")
+	ErrFraudScoringHookUnavailable = errors.New("hook de scoring indisponível")
+)
+
+// FraudFeedbackConfig contém configurações do ciclo de feedback de fraude
+type FraudFeedbackConfig struct {
+	// Período das contagens de velocidade por usuário, dispositivo e comerciante
+	VelocityWindow time.Duration `json:"velocity_window"`
+
+	// Serviço de ML que recebe os rótulos confirmados como sinais de treino; vazio desativa
+	ScoringHookURL string `json:"scoring_hook_url"`
+	APIKey         string `json:"-"`
+
+	HTTPTimeout time.Duration `json:"http_timeout"`
+
+	// Resilience sobrepõe HTTPTimeout e define as novas tentativas das chamadas ao serviço de ML
+	Resilience resilience.Policy `json:"resilience"`
+}
+
+// FraudFeedback é o rótulo confirmado de uma transação, reportado pelo emissor ou pelo comerciante
+type FraudFeedback struct {
+	FeedbackID    string    `json:"feedback_id" db:"feedback_id"`
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	MerchantID    string    `json:"merchant_id" db:"merchant_id"`
+	Label         string    `json:"label" db:"label"`
+	Source        string    `json:"source" db:"source"`
+	Reason        string    `json:"reason,omitempty" db:"reason"`
+	ReportedBy    string    `json:"reported_by,omitempty" db:"reported_by"`
+	PreviousLabel string    `json:"previous_label,omitempty" db:"previous_label"` // Rótulo substituído quando a transação é reclassificada
+	ReportedAt    time.Time `json:"reported_at" db:"reported_at"`
+}
+
+// FraudFeedbackResult descreve a propagação de um rótulo
+type FraudFeedbackResult struct {
+	Feedback                FraudFeedback           `json:"feedback"`
+	Duplicate               bool                    `json:"duplicate"`                 // Rótulo igual ao já registado; nada foi propagado
+	TrainingSignalDelivered bool                    `json:"training_signal_delivered"` // Sinal aceite pelo hook de scoring
+	TrainingSignalError     string                  `json:"training_signal_error,omitempty"`
+	MerchantProfile         MerchantRiskProfile     `json:"merchant_profile"`
+	Adjustment              *MerchantRiskAdjustment `json:"adjustment,omitempty"` // Presente quando o nível do comerciante mudou
+}
+
+// FraudTrainingSignal é o exemplo rotulado enviado ao modelo de scoring
+type FraudTrainingSignal struct {
+	TenantID          string    `json:"tenant_id"`
+	TransactionID     string    `json:"transaction_id"`
+	MerchantID        string    `json:"merchant_id"`
+	UserID            string    `json:"user_id"`
+	DeviceFingerprint string    `json:"device_fingerprint,omitempty"`
+	PaymentMethod     string    `json:"payment_method"`
+	Amount            float64   `json:"amount"`
+	Currency          string    `json:"currency"`
+	Market            string    `json:"market"`
+	RiskScore         float64   `json:"risk_score"`
+	TriggeredRules    []string  `json:"triggered_rules"`
+	EvaluatedAt       time.Time `json:"evaluated_at"`
+	Label             string    `json:"label"`
+	Source            string    `json:"source"`
+	LabelledAt        time.Time `json:"labelled_at"`
+}
+
+// VelocityStats resume a atividade recente e os rótulos confirmados de um usuário, dispositivo ou comerciante
+type VelocityStats struct {
+	Key            string `json:"key"`
+	Transactions   int    `json:"transactions"` // Transações avaliadas no período de velocidade
+	ConfirmedFraud int    `json:"confirmed_fraud"`
+	ConfirmedLegit int    `json:"confirmed_legit"`
+}
+
+// MerchantRiskProfile é o perfil de risco de um comerciante ajustado pelos rótulos confirmados
+type MerchantRiskProfile struct {
+	TenantID       string    `json:"tenant_id" db:"tenant_id"`
+	MerchantID     string    `json:"merchant_id" db:"merchant_id"`
+	Transactions   int64     `json:"transactions" db:"transactions"` // Transações avaliadas desde o início do perfil
+	ConfirmedFraud int64     `json:"confirmed_fraud" db:"confirmed_fraud"`
+	ConfirmedLegit int64     `json:"confirmed_legit" db:"confirmed_legit"`
+	FraudAmount    float64   `json:"fraud_amount" db:"fraud_amount"`
+	FraudRate      float64   `json:"fraud_rate" db:"fraud_rate"`
+	Level          string    `json:"level" db:"level"`
+	RiskScore      float64   `json:"risk_score" db:"risk_score"` // Contribuição da regra merchant_risk_profile
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MerchantRiskAdjustment regista cada alteração automática do nível de risco de um comerciante
+type MerchantRiskAdjustment struct {
+	AdjustmentID  string    `json:"adjustment_id" db:"adjustment_id"`
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	MerchantID    string    `json:"merchant_id" db:"merchant_id"`
+	FeedbackID    string    `json:"feedback_id" db:"feedback_id"`
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	PreviousLevel string    `json:"previous_level" db:"previous_level"`
+	NewLevel      string    `json:"new_level" db:"new_level"`
+	PreviousScore float64   `json:"previous_score" db:"previous_score"`
+	NewScore      float64   `json:"new_score" db:"new_score"`
+	FraudRate     float64   `json:"fraud_rate" db:"fraud_rate"`
+	Reason        string    `json:"reason" db:"reason"`
+	AdjustedBy    string    `json:"adjusted_by,omitempty" db:"adjusted_by"` // Operador que registou o rótulo
+	AdjustedAt    time.Time `json:"adjusted_at" db:"adjusted_at"`
+}
+
+// FraudHistory resume as fraudes confirmadas do usuário e do dispositivo e o perfil do comerciante
+// de um pagamento, usado pelas regras de risco alimentadas pelo feedback
+type FraudHistory struct {
+	UserConfirmedFraud   int     `json:"user_confirmed_fraud"`
+	DeviceConfirmedFraud int     `json:"device_confirmed_fraud"`
+	MerchantLevel        string  `json:"merchant_level"`
+	MerchantRiskScore    float64 `json:"merchant_risk_score"`
+	MerchantFraudRate    float64 `json:"merchant_fraud_rate"`
+}
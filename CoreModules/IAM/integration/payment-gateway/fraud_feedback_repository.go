@@ -0,0 +1,163 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresFraudFeedbackStore implementa FraudFeedbackStore para PostgreSQL
+type PostgresFraudFeedbackStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresFraudFeedbackStore cria uma nova instância de PostgresFraudFeedbackStore
+func NewPostgresFraudFeedbackStore(db *sqlx.DB) *PostgresFraudFeedbackStore {
+	return &PostgresFraudFeedbackStore{db: db}
+}
+
+// fraudFeedbackColumns são as colunas lidas dos rótulos
+const fraudFeedbackColumns = `feedback_id, tenant_id, transaction_id, merchant_id, label, source, reason,
+	reported_by, previous_label, reported_at`
+
+// SaveFraudFeedback grava o rótulo da transação, substituindo o anterior
+func (r *PostgresFraudFeedbackStore) SaveFraudFeedback(ctx context.Context, feedback *FraudFeedback) error {
+	query := `
+		INSERT INTO payment_gateway.fraud_feedback (
+			feedback_id, tenant_id, transaction_id, merchant_id, label, source, reason,
+			reported_by, previous_label, reported_at
+		) VALUES (
+			:feedback_id, :tenant_id, :transaction_id, :merchant_id, :label, :source, :reason,
+			:reported_by, :previous_label, :reported_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			feedback_id = EXCLUDED.feedback_id,
+			merchant_id = EXCLUDED.merchant_id,
+			label = EXCLUDED.label,
+			source = EXCLUDED.source,
+			reason = EXCLUDED.reason,
+			reported_by = EXCLUDED.reported_by,
+			previous_label = EXCLUDED.previous_label,
+			reported_at = EXCLUDED.reported_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, feedback); err != nil {
+		return fmt.Errorf("falha ao gravar rótulo de fraude: %w", err)
+	}
+
+	return nil
+}
+
+// GetFraudFeedback recupera o rótulo da transação do tenant
+func (r *PostgresFraudFeedbackStore) GetFraudFeedback(ctx context.Context, tenantID, transactionID string) (*FraudFeedback, error) {
+	var feedback FraudFeedback
+	query := `SELECT ` + fraudFeedbackColumns + `
+		FROM payment_gateway.fraud_feedback
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	if err := r.db.GetContext(ctx, &feedback, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFraudFeedbackNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar rótulo de fraude: %w", err)
+	}
+	return &feedback, nil
+}
+
+// ListFraudFeedback lista os rótulos do tenant por ordem de reporte
+func (r *PostgresFraudFeedbackStore) ListFraudFeedback(ctx context.Context, tenantID, merchantID string) ([]*FraudFeedback, error) {
+	list := make([]*FraudFeedback, 0)
+	query := `SELECT ` + fraudFeedbackColumns + `
+		FROM payment_gateway.fraud_feedback
+		WHERE tenant_id = $1 AND ($2 = '' OR merchant_id = $2)
+		ORDER BY reported_at
+	`
+	if err := r.db.SelectContext(ctx, &list, query, tenantID, merchantID); err != nil {
+		return nil, fmt.Errorf("falha ao listar rótulos de fraude: %w", err)
+	}
+	return list, nil
+}
+
+// SaveMerchantRiskProfile grava o perfil de risco do comerciante, substituindo o anterior
+func (r *PostgresFraudFeedbackStore) SaveMerchantRiskProfile(ctx context.Context, profile *MerchantRiskProfile) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_risk_profiles (
+			tenant_id, merchant_id, transactions, confirmed_fraud, confirmed_legit, fraud_amount,
+			fraud_rate, level, risk_score, updated_at
+		) VALUES (
+			:tenant_id, :merchant_id, :transactions, :confirmed_fraud, :confirmed_legit, :fraud_amount,
+			:fraud_rate, :level, :risk_score, :updated_at
+		)
+		ON CONFLICT (tenant_id, merchant_id) DO UPDATE SET
+			transactions = EXCLUDED.transactions,
+			confirmed_fraud = EXCLUDED.confirmed_fraud,
+			confirmed_legit = EXCLUDED.confirmed_legit,
+			fraud_amount = EXCLUDED.fraud_amount,
+			fraud_rate = EXCLUDED.fraud_rate,
+			level = EXCLUDED.level,
+			risk_score = EXCLUDED.risk_score,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, profile); err != nil {
+		return fmt.Errorf("falha ao gravar perfil de risco do comerciante: %w", err)
+	}
+
+	return nil
+}
+
+// GetMerchantRiskProfile recupera o perfil de risco do comerciante do tenant
+func (r *PostgresFraudFeedbackStore) GetMerchantRiskProfile(ctx context.Context, tenantID, merchantID string) (*MerchantRiskProfile, error) {
+	var profile MerchantRiskProfile
+	query := `
+		SELECT tenant_id, merchant_id, transactions, confirmed_fraud, confirmed_legit, fraud_amount,
+			fraud_rate, level, risk_score, updated_at
+		FROM payment_gateway.merchant_risk_profiles
+		WHERE tenant_id = $1 AND merchant_id = $2
+	`
+	if err := r.db.GetContext(ctx, &profile, query, tenantID, merchantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMerchantRiskProfileNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar perfil de risco do comerciante: %w", err)
+	}
+	return &profile, nil
+}
+
+// SaveMerchantRiskAdjustment grava um ajuste do perfil de risco do comerciante
+func (r *PostgresFraudFeedbackStore) SaveMerchantRiskAdjustment(ctx context.Context, adjustment *MerchantRiskAdjustment) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_risk_adjustments (
+			adjustment_id, tenant_id, merchant_id, feedback_id, transaction_id, previous_level, new_level,
+			previous_score, new_score, fraud_rate, reason, adjusted_by, adjusted_at
+		) VALUES (
+			:adjustment_id, :tenant_id, :merchant_id, :feedback_id, :transaction_id, :previous_level, :new_level,
+			:previous_score, :new_score, :fraud_rate, :reason, :adjusted_by, :adjusted_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, adjustment); err != nil {
+		return fmt.Errorf("falha ao gravar ajuste do perfil de risco do comerciante: %w", err)
+	}
+
+	return nil
+}
+
+// ListMerchantRiskAdjustments lista os ajustes do comerciante do tenant por ordem de ajuste
+func (r *PostgresFraudFeedbackStore) ListMerchantRiskAdjustments(ctx context.Context, tenantID, merchantID string) ([]*MerchantRiskAdjustment, error) {
+	list := make([]*MerchantRiskAdjustment, 0)
+	query := `
+		SELECT adjustment_id, tenant_id, merchant_id, feedback_id, transaction_id, previous_level, new_level,
+			previous_score, new_score, fraud_rate, reason, adjusted_by, adjusted_at
+		FROM payment_gateway.merchant_risk_adjustments
+		WHERE tenant_id = $1 AND merchant_id = $2
+		ORDER BY adjusted_at
+	`
+	if err := r.db.SelectContext(ctx, &list, query, tenantID, merchantID); err != nil {
+		return nil, fmt.Errorf("falha ao listar ajustes do perfil de risco do comerciante: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,388 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// FraudFeedbackService recebe os rótulos de fraude confirmados pelos emissores e comerciantes depois
+// do processamento e propaga-os ao store de velocidade, ao hook de scoring do modelo de ML, à taxa de
+// fraude das isenções SCA e ao perfil de risco do comerciante, auditando cada ajuste automático
+type FraudFeedbackService struct {
+	config        FraudFeedbackConfig
+	store         FraudFeedbackStore
+	velocity      FraudVelocityStore
+	records       TransactionRecordStore
+	hook          FraudScoringHook // Ausente quando não há serviço de ML configurado
+	scaExemptions *SCAExemptionService
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+
+	// mutex serializa a leitura-modificação-escrita dos rótulos e perfis
+	mutex sync.Mutex
+}
+
+// NewFraudFeedbackService cria o serviço de feedback de fraude. records é o armazenamento dos
+// registos do motor de risco, que identifica as transações rotuladas. Sem velocity é usado um store
+// em memória; sem hook é usado o cliente HTTP de config.ScoringHookURL, quando indicado
+func NewFraudFeedbackService(config FraudFeedbackConfig, store FraudFeedbackStore, velocity FraudVelocityStore, records TransactionRecordStore, hook FraudScoringHook) (*FraudFeedbackService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-fraud-feedback",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.VelocityWindow <= 0 {
+		config.VelocityWindow = DefaultFraudVelocityWindow
+	}
+	if velocity == nil {
+		velocity = NewInMemoryFraudVelocityStore(config.VelocityWindow)
+	}
+	if hook == nil && config.ScoringHookURL != "" {
+		hook = NewHTTPFraudScoringHook(config)
+	}
+
+	service := &FraudFeedbackService{
+		config:          config,
+		store:           store,
+		velocity:        velocity,
+		records:         records,
+		hook:            hook,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+
+	service.logger.Info("Serviço de feedback de fraude inicializado",
+		"velocity_window", config.VelocityWindow.String(),
+		"scoring_hook", hook != nil)
+	return service, nil
+}
+
+// SetSCAExemptionService propaga as fraudes confirmadas à taxa de fraude usada na isenção TRA
+func (s *FraudFeedbackService) SetSCAExemptionService(scaExemptions *SCAExemptionService) {
+	s.scaExemptions = scaExemptions
+}
+
+// History resume as fraudes confirmadas do usuário e do dispositivo e o perfil do comerciante do
+// pagamento para as regras de risco alimentadas pelo feedback
+func (s *FraudFeedbackService) History(ctx context.Context, req *PaymentRequest) (*FraudHistory, error) {
+	history := &FraudHistory{MerchantLevel: MerchantRiskLow}
+	now := s.now().UTC()
+
+	if req.UserID != "" {
+		stats, err := s.velocity.Stats(ctx, velocityKeys(req.TenantID, req.UserID, "", "")[0], now)
+		if err != nil {
+			return nil, err
+		}
+		history.UserConfirmedFraud = stats.ConfirmedFraud
+	}
+	if fingerprint := req.DeviceInfo.DeviceFingerprint; fingerprint != "" {
+		stats, err := s.velocity.Stats(ctx, velocityKeys(req.TenantID, "", fingerprint, "")[0], now)
+		if err != nil {
+			return nil, err
+		}
+		history.DeviceConfirmedFraud = stats.ConfirmedFraud
+	}
+
+	profile, err := s.GetMerchantRiskProfile(ctx, req.TenantID, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	history.MerchantLevel = profile.Level
+	history.MerchantRiskScore = profile.RiskScore
+	history.MerchantFraudRate = profile.FraudRate
+	return history, nil
+}
+
+// RecordTransaction contabiliza a transação avaliada pelo motor de risco na velocidade e no perfil
+// do comerciante. As falhas são registadas sem interromper o pagamento
+func (s *FraudFeedbackService) RecordTransaction(ctx context.Context, req *PaymentRequest) {
+	ctx, span := s.tracer.StartSpan(ctx, "FraudFeedbackService.RecordTransaction")
+	defer span.End()
+
+	keys := velocityKeys(req.TenantID, req.UserID, req.DeviceInfo.DeviceFingerprint, req.MerchantID)
+	if err := s.velocity.RecordTransaction(ctx, keys, s.now().UTC()); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao registar velocidade da transação",
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, err := s.GetMerchantRiskProfile(ctx, req.TenantID, req.MerchantID)
+	if err == nil {
+		profile.Transactions++
+		profile.UpdatedAt = s.now().UTC()
+		err = s.store.SaveMerchantRiskProfile(ctx, profile)
+	}
+	if err != nil {
+		span.RecordError(err)
+		s.logger.WarnWithContext(ctx, "Falha ao atualizar perfil de risco do comerciante",
+			"merchant_id", req.MerchantID,
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// Submit regista o rótulo de uma transação avaliada pelo motor de risco e propaga-o. Um rótulo igual
+// ao já registado não é propagado de novo; um rótulo diferente reclassifica a transação, retirando o
+// anterior das contagens. Transações desconhecidas retornam ErrTransactionRecordNotFound
+func (s *FraudFeedbackService) Submit(ctx context.Context, feedback FraudFeedback) (*FraudFeedbackResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "FraudFeedbackService.Submit")
+	defer span.End()
+
+	if feedback.Label != FraudLabelFraud && feedback.Label != FraudLabelLegit {
+		return nil, fmt.Errorf("%w: label deve ser %s ou %s", ErrFraudFeedbackInvalid, FraudLabelFraud, FraudLabelLegit)
+	}
+	if feedback.Source != FraudFeedbackSourceIssuer && feedback.Source != FraudFeedbackSourceMerchant {
+		return nil, fmt.Errorf("%w: source deve ser %s ou %s", ErrFraudFeedbackInvalid, FraudFeedbackSourceIssuer, FraudFeedbackSourceMerchant)
+	}
+
+	record, err := s.records.GetTransactionRecord(ctx, feedback.TenantID, feedback.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if record.RiskExplanation != nil && record.RiskExplanation.Sandbox {
+		return nil, ErrFraudFeedbackSandbox
+	}
+
+	result, err := s.label(ctx, record, feedback)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if result.Duplicate {
+		return result, nil
+	}
+	feedback = result.Feedback
+
+	s.logger.InfoWithContext(ctx, "Rótulo de fraude registado",
+		"tenant_id", feedback.TenantID,
+		"transaction_id", feedback.TransactionID,
+		"merchant_id", feedback.MerchantID,
+		"label", feedback.Label,
+		"previous_label", feedback.PreviousLabel,
+		"source", feedback.Source,
+		"reported_by", feedback.ReportedBy)
+	s.metricsRecorder.CounterInc("payment_gateway_fraud_feedback_total", map[string]string{
+		"source": feedback.Source,
+		"label":  feedback.Label,
+	})
+
+	if adjustment := result.Adjustment; adjustment != nil {
+		s.logger.InfoWithContext(ctx, "Perfil de risco do comerciante ajustado",
+			"tenant_id", adjustment.TenantID,
+			"merchant_id", adjustment.MerchantID,
+			"adjustment_id", adjustment.AdjustmentID,
+			"previous_level", adjustment.PreviousLevel,
+			"new_level", adjustment.NewLevel,
+			"fraud_rate", adjustment.FraudRate)
+		s.metricsRecorder.CounterInc("payment_gateway_merchant_risk_adjustments_total", map[string]string{
+			"new_level": adjustment.NewLevel,
+		})
+	}
+
+	// A fraude confirmada também conta na taxa de fraude do instrumento usada na isenção TRA; os
+	// pagamentos sem decisão de isenção ou ainda não concluídos não entram nessa taxa
+	if s.scaExemptions != nil && feedback.Label == FraudLabelFraud {
+		if _, err := s.scaExemptions.ReportFraud(ctx, feedback.TenantID, feedback.TransactionID); err != nil &&
+			!errors.Is(err, ErrSCADecisionNotFound) && !errors.Is(err, ErrSCATransactionNotCompleted) {
+			s.logger.WarnWithContext(ctx, "Falha ao propagar fraude confirmada às isenções SCA",
+				"transaction_id", feedback.TransactionID,
+				"error", err.Error())
+		}
+	}
+
+	// Sinal de treino enviado fora do lock: o serviço de ML pode demorar até ao timeout
+	if s.hook != nil {
+		if err := s.hook.RecordLabel(ctx, trainingSignal(record, feedback)); err != nil {
+			result.TrainingSignalError = err.Error()
+			s.logger.WarnWithContext(ctx, "Falha ao enviar sinal de treino ao hook de scoring",
+				"transaction_id", feedback.TransactionID,
+				"label", feedback.Label,
+				"error", err.Error())
+		} else {
+			result.TrainingSignalDelivered = true
+		}
+	}
+	return result, nil
+}
+
+// label grava o rótulo e atualiza a velocidade e o perfil do comerciante
+func (s *FraudFeedbackService) label(ctx context.Context, record *TransactionRecord, feedback FraudFeedback) (*FraudFeedbackResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, err := s.GetMerchantRiskProfile(ctx, record.TenantID, record.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.store.GetFraudFeedback(ctx, record.TenantID, record.TransactionID)
+	switch {
+	case errors.Is(err, ErrFraudFeedbackNotFound):
+	case err != nil:
+		return nil, err
+	case previous.Label == feedback.Label:
+		return &FraudFeedbackResult{Feedback: *previous, Duplicate: true, MerchantProfile: *profile}, nil
+	default:
+		feedback.PreviousLabel = previous.Label
+	}
+
+	feedback.FeedbackID = uuid.New().String()
+	feedback.MerchantID = record.MerchantID
+	feedback.ReportedAt = s.now().UTC()
+	if err := s.store.SaveFraudFeedback(ctx, &feedback); err != nil {
+		return nil, err
+	}
+
+	keys := velocityKeys(record.TenantID, record.UserID, record.DeviceFingerprint, record.MerchantID)
+	if err := s.velocity.Label(ctx, keys, feedback.Label, feedback.PreviousLabel); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao propagar rótulo ao store de velocidade",
+			"transaction_id", feedback.TransactionID,
+			"error", err.Error())
+	}
+
+	adjustment := adjustMerchantRiskProfile(profile, feedback, record.Amount)
+	if err := s.store.SaveMerchantRiskProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	if adjustment != nil {
+		adjustment.AdjustmentID = uuid.New().String()
+		if err := s.store.SaveMerchantRiskAdjustment(ctx, adjustment); err != nil {
+			return nil, err
+		}
+	}
+
+	return &FraudFeedbackResult{Feedback: feedback, MerchantProfile: *profile, Adjustment: adjustment}, nil
+}
+
+// ListFeedback lista os rótulos do tenant, filtrados pelo comerciante quando indicado
+func (s *FraudFeedbackService) ListFeedback(ctx context.Context, tenantID, merchantID string) ([]*FraudFeedback, error) {
+	return s.store.ListFraudFeedback(ctx, tenantID, merchantID)
+}
+
+// GetMerchantRiskProfile retorna o perfil de risco do comerciante; os comerciantes sem transações
+// avaliadas têm risco baixo
+func (s *FraudFeedbackService) GetMerchantRiskProfile(ctx context.Context, tenantID, merchantID string) (*MerchantRiskProfile, error) {
+	profile, err := s.store.GetMerchantRiskProfile(ctx, tenantID, merchantID)
+	if errors.Is(err, ErrMerchantRiskProfileNotFound) {
+		return &MerchantRiskProfile{TenantID: tenantID, MerchantID: merchantID, Level: MerchantRiskLow}, nil
+	}
+	return profile, err
+}
+
+// ListMerchantRiskAdjustments retorna a auditoria dos ajustes automáticos do perfil do comerciante
+func (s *FraudFeedbackService) ListMerchantRiskAdjustments(ctx context.Context, tenantID, merchantID string) ([]*MerchantRiskAdjustment, error) {
+	return s.store.ListMerchantRiskAdjustments(ctx, tenantID, merchantID)
+}
+
+// adjustMerchantRiskProfile atualiza as contagens do comerciante com o rótulo e recalcula o seu
+// nível; retorna o ajuste quando o nível muda
+func adjustMerchantRiskProfile(profile *MerchantRiskProfile, feedback FraudFeedback, amount float64) *MerchantRiskAdjustment {
+	switch feedback.PreviousLabel {
+	case FraudLabelFraud:
+		profile.ConfirmedFraud--
+		profile.FraudAmount = roundAmount(profile.FraudAmount - amount)
+	case FraudLabelLegit:
+		profile.ConfirmedLegit--
+	}
+	if feedback.Label == FraudLabelFraud {
+		profile.ConfirmedFraud++
+		profile.FraudAmount = roundAmount(profile.FraudAmount + amount)
+	} else {
+		profile.ConfirmedLegit++
+	}
+
+	previousLevel, previousScore := profile.Level, profile.RiskScore
+	profile.FraudRate, profile.Level = merchantRiskLevel(profile)
+	profile.RiskScore = merchantRiskScores[profile.Level]
+	profile.UpdatedAt = feedback.ReportedAt
+	if profile.Level == previousLevel {
+		return nil
+	}
+
+	return &MerchantRiskAdjustment{
+		TenantID:      profile.TenantID,
+		MerchantID:    profile.MerchantID,
+		FeedbackID:    feedback.FeedbackID,
+		TransactionID: feedback.TransactionID,
+		PreviousLevel: previousLevel,
+		NewLevel:      profile.Level,
+		PreviousScore: previousScore,
+		NewScore:      profile.RiskScore,
+		FraudRate:     profile.FraudRate,
+		Reason: fmt.Sprintf("%d fraude(s) confirmada(s) em %d transação(ões) avaliada(s) após rótulo %s de %s",
+			profile.ConfirmedFraud, profile.Transactions, feedback.Label, feedback.Source),
+		AdjustedBy: feedback.ReportedBy,
+		AdjustedAt: feedback.ReportedAt,
+	}
+}
+
+// merchantRiskLevel calcula a taxa de fraude do comerciante e o nível correspondente. O nível só
+// sobe depois de merchantRiskMinFraudReports fraudes confirmadas
+func merchantRiskLevel(profile *MerchantRiskProfile) (float64, string) {
+	total := profile.Transactions
+	if labelled := profile.ConfirmedFraud + profile.ConfirmedLegit; labelled > total {
+		total = labelled
+	}
+	if total == 0 {
+		return 0, MerchantRiskLow
+	}
+	rate := math.Round(float64(profile.ConfirmedFraud)/float64(total)*10000) / 10000
+
+	switch {
+	case profile.ConfirmedFraud < merchantRiskMinFraudReports:
+		return rate, MerchantRiskLow
+	case rate >= 0.10:
+		return rate, MerchantRiskCritical
+	case rate >= 0.05:
+		return rate, MerchantRiskHigh
+	case rate >= 0.01:
+		return rate, MerchantRiskElevated
+	}
+	return rate, MerchantRiskLow
+}
+
+// trainingSignal monta o exemplo rotulado a partir do registo da avaliação de risco
+func trainingSignal(record *TransactionRecord, feedback FraudFeedback) *FraudTrainingSignal {
+	signal := &FraudTrainingSignal{
+		TenantID:          record.TenantID,
+		TransactionID:     record.TransactionID,
+		MerchantID:        record.MerchantID,
+		UserID:            record.UserID,
+		DeviceFingerprint: record.DeviceFingerprint,
+		PaymentMethod:     record.PaymentMethod,
+		Amount:            record.Amount,
+		Currency:          record.Currency,
+		RiskScore:         record.RiskScore,
+		TriggeredRules:    []string{},
+		EvaluatedAt:       record.RecordedAt,
+		Label:             feedback.Label,
+		Source:            feedback.Source,
+		LabelledAt:        feedback.ReportedAt,
+	}
+	if record.RiskExplanation != nil {
+		signal.Market = record.RiskExplanation.Market
+		signal.TriggeredRules = record.RiskExplanation.RuleIDs()
+	}
+	return signal
+}
@@ -0,0 +1,366 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFraudScoringHook guarda os sinais de treino recebidos
+type fakeFraudScoringHook struct {
+	mutex   sync.Mutex
+	signals []FraudTrainingSignal
+	err     error
+}
+
+func (h *fakeFraudScoringHook) RecordLabel(ctx context.Context, signal *FraudTrainingSignal) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.err != nil {
+		return h.err
+	}
+	h.signals = append(h.signals, *signal)
+	return nil
+}
+
+func (h *fakeFraudScoringHook) received() []FraudTrainingSignal {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]FraudTrainingSignal(nil), h.signals...)
+}
+
+// newTestFraudFeedbackService cria o serviço com o motor de risco que regista as transações rotuladas
+func newTestFraudFeedbackService(t *testing.T, hook FraudScoringHook) (*FraudFeedbackService, *RiskEngine, *InMemoryFraudVelocityStore) {
+	t.Helper()
+
+	records := NewInMemoryTransactionRecordStore()
+	velocity := NewInMemoryFraudVelocityStore(time.Hour)
+	service, err := NewFraudFeedbackService(FraudFeedbackConfig{}, NewInMemoryFraudFeedbackStore(), velocity, records, hook)
+	require.NoError(t, err)
+	service.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return service, newTestRiskEngine(t, records), velocity
+}
+
+// evaluateTestFraudTransaction avalia o pagamento no motor de risco e regista-o no feedback
+func evaluateTestFraudTransaction(t *testing.T, service *FraudFeedbackService, engine *RiskEngine, transactionID string) *PaymentRequest {
+	t.Helper()
+
+	req := testRiskRequest(RegionUSA, "USD", 100)
+	req.TransactionID = transactionID
+	req.DeviceInfo.DeviceFingerprint = "fp-1"
+	_, err := engine.EvaluateTransaction(context.Background(), req)
+	require.NoError(t, err)
+	service.RecordTransaction(context.Background(), req)
+	return req
+}
+
+func TestFraudFeedbackSubmitRejectsInvalidFeedback(t *testing.T) {
+	service, engine, _ := newTestFraudFeedbackService(t, nil)
+	evaluateTestFraudTransaction(t, service, engine, "tx-1")
+	sandbox := testRiskRequest(RegionUSA, "USD", 100)
+	sandbox.TransactionID = "tx-sandbox"
+	sandbox.Sandbox = true
+	_, err := engine.EvaluateTransaction(context.Background(), sandbox)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		feedback FraudFeedback
+		err      error
+	}{
+		{"rótulo desconhecido", FraudFeedback{TransactionID: "tx-1", Label: "chargeback", Source: FraudFeedbackSourceIssuer}, ErrFraudFeedbackInvalid},
+		{"origem desconhecida", FraudFeedback{TransactionID: "tx-1", Label: FraudLabelFraud, Source: "acquirer"}, ErrFraudFeedbackInvalid},
+		{"transação não avaliada", FraudFeedback{TransactionID: "tx-2", Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer}, ErrTransactionRecordNotFound},
+		{"transação em sandbox", FraudFeedback{TransactionID: "tx-sandbox", Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer}, ErrFraudFeedbackSandbox},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.feedback.TenantID = "tenant-1"
+			_, err := service.Submit(context.Background(), tt.feedback)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestFraudFeedbackPropagatesLabel(t *testing.T) {
+	ctx := context.Background()
+	hook := &fakeFraudScoringHook{}
+	service, engine, velocity := newTestFraudFeedbackService(t, hook)
+	evaluateTestFraudTransaction(t, service, engine, "tx-1")
+	at := service.now()
+
+	result, err := service.Submit(ctx, FraudFeedback{
+		TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer, ReportedBy: "operador-1",
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Duplicate)
+	assert.True(t, result.TrainingSignalDelivered)
+	assert.Equal(t, "merchant-1", result.Feedback.MerchantID)
+	assert.Empty(t, result.Feedback.PreviousLabel)
+	assert.Nil(t, result.Adjustment, "um reporte isolado não altera o nível do comerciante")
+
+	for _, key := range velocityKeys("tenant-1", "user-1", "fp-1", "merchant-1") {
+		stats, err := velocity.Stats(ctx, key, at)
+		require.NoError(t, err)
+		assert.Equal(t, VelocityStats{Key: key, Transactions: 1, ConfirmedFraud: 1}, stats)
+	}
+
+	signals := hook.received()
+	require.Len(t, signals, 1)
+	assert.Equal(t, FraudLabelFraud, signals[0].Label)
+	assert.Equal(t, "fp-1", signals[0].DeviceFingerprint)
+	assert.Equal(t, RegionUSA, signals[0].Market)
+	assert.NotNil(t, signals[0].TriggeredRules)
+
+	t.Run("rótulo repetido não é propagado", func(t *testing.T) {
+		result, err := service.Submit(ctx, FraudFeedback{
+			TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelFraud, Source: FraudFeedbackSourceMerchant,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Duplicate)
+		assert.Len(t, hook.received(), 1)
+	})
+
+	t.Run("reclassificação retira o rótulo anterior", func(t *testing.T) {
+		result, err := service.Submit(ctx, FraudFeedback{
+			TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelLegit, Source: FraudFeedbackSourceMerchant,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, FraudLabelFraud, result.Feedback.PreviousLabel)
+		assert.Equal(t, int64(0), result.MerchantProfile.ConfirmedFraud)
+		assert.Equal(t, int64(1), result.MerchantProfile.ConfirmedLegit)
+
+		stats, err := velocity.Stats(ctx, velocityKeys("tenant-1", "user-1", "", "")[0], at)
+		require.NoError(t, err)
+		assert.Equal(t, 0, stats.ConfirmedFraud)
+		assert.Equal(t, 1, stats.ConfirmedLegit)
+		assert.Len(t, hook.received(), 2)
+	})
+}
+
+func TestFraudFeedbackHookFailureKeepsLabel(t *testing.T) {
+	hook := &fakeFraudScoringHook{err: fmt.Errorf("%w: status 503", ErrFraudScoringHookUnavailable)}
+	service, engine, _ := newTestFraudFeedbackService(t, hook)
+	evaluateTestFraudTransaction(t, service, engine, "tx-1")
+
+	result, err := service.Submit(context.Background(), FraudFeedback{
+		TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.TrainingSignalDelivered)
+	assert.Contains(t, result.TrainingSignalError, "503")
+
+	list, err := service.ListFeedback(context.Background(), "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestFraudFeedbackAdjustsMerchantProfile(t *testing.T) {
+	ctx := context.Background()
+	service, engine, _ := newTestFraudFeedbackService(t, nil)
+	for i := 1; i <= 20; i++ {
+		evaluateTestFraudTransaction(t, service, engine, fmt.Sprintf("tx-%d", i))
+	}
+
+	var last *FraudFeedbackResult
+	for i := 1; i <= 3; i++ {
+		var err error
+		last, err = service.Submit(ctx, FraudFeedback{
+			TenantID: "tenant-1", TransactionID: fmt.Sprintf("tx-%d", i), Label: FraudLabelFraud,
+			Source: FraudFeedbackSourceIssuer, ReportedBy: "operador-1",
+		})
+		require.NoError(t, err)
+		if i < 3 {
+			assert.Nil(t, last.Adjustment)
+		}
+	}
+
+	require.NotNil(t, last.Adjustment)
+	assert.Equal(t, MerchantRiskLow, last.Adjustment.PreviousLevel)
+	assert.Equal(t, MerchantRiskCritical, last.Adjustment.NewLevel)
+	assert.Equal(t, 0.85, last.Adjustment.NewScore)
+	assert.Equal(t, 0.15, last.Adjustment.FraudRate)
+	assert.Equal(t, "operador-1", last.Adjustment.AdjustedBy)
+	assert.Equal(t, last.Feedback.FeedbackID, last.Adjustment.FeedbackID)
+
+	profile, err := service.GetMerchantRiskProfile(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), profile.Transactions)
+	assert.Equal(t, int64(3), profile.ConfirmedFraud)
+	assert.Equal(t, 300.0, profile.FraudAmount)
+
+	adjustments, err := service.ListMerchantRiskAdjustments(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, last.Adjustment.AdjustmentID, adjustments[0].AdjustmentID)
+
+	history, err := service.History(ctx, testRiskRequest(RegionUSA, "USD", 100))
+	require.NoError(t, err)
+	assert.Equal(t, &FraudHistory{
+		UserConfirmedFraud: 3, MerchantLevel: MerchantRiskCritical, MerchantRiskScore: 0.85, MerchantFraudRate: 0.15,
+	}, history)
+}
+
+func TestMerchantRiskLevel(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions int64
+		fraud        int64
+		legit        int64
+		rate         float64
+		level        string
+	}{
+		{"sem transações", 0, 0, 0, 0, MerchantRiskLow},
+		{"abaixo do mínimo de reportes", 10, 2, 0, 0.2, MerchantRiskLow},
+		{"taxa abaixo de 1%", 1000, 5, 0, 0.005, MerchantRiskLow},
+		{"taxa elevada", 200, 3, 0, 0.015, MerchantRiskElevated},
+		{"taxa alta", 100, 5, 0, 0.05, MerchantRiskHigh},
+		{"taxa crítica", 30, 3, 0, 0.1, MerchantRiskCritical},
+		{"rótulos acima das transações avaliadas", 2, 3, 1, 0.75, MerchantRiskCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, level := merchantRiskLevel(&MerchantRiskProfile{
+				Transactions: tt.transactions, ConfirmedFraud: tt.fraud, ConfirmedLegit: tt.legit,
+			})
+			assert.Equal(t, tt.rate, rate)
+			assert.Equal(t, tt.level, level)
+		})
+	}
+}
+
+func TestFraudFeedbackReportsFraudToSCAExemptions(t *testing.T) {
+	ctx := context.Background()
+	service, engine, _ := newTestFraudFeedbackService(t, nil)
+	sca, store, _ := newTestSCAExemptionService(t)
+	service.SetSCAExemptionService(sca)
+
+	req := evaluateTestFraudTransaction(t, service, engine, "tx-1")
+	require.NoError(t, store.SaveSCADecision(ctx, &SCAExemptionDecision{
+		TenantID: "tenant-1", TransactionID: "tx-1", Instrument: SCAInstrumentCard, Amount: req.Amount, Completed: true,
+	}))
+	evaluateTestFraudTransaction(t, service, engine, "tx-2")
+
+	for _, transactionID := range []string{"tx-1", "tx-2"} {
+		_, err := service.Submit(ctx, FraudFeedback{
+			TenantID: "tenant-1", TransactionID: transactionID, Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer,
+		})
+		require.NoError(t, err, "transações sem decisão SCA também são rotuladas")
+	}
+
+	decision, err := store.GetSCADecision(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.True(t, decision.Fraudulent)
+}
+
+func TestProcessPaymentUsesConfirmedFraudHistory(t *testing.T) {
+	ctx := context.Background()
+	connector, _ := newTestConnector(t)
+	records := NewInMemoryTransactionRecordStore()
+	connector.SetRiskEngine(newTestRiskEngine(t, records))
+	service, err := NewFraudFeedbackService(FraudFeedbackConfig{}, NewInMemoryFraudFeedbackStore(), nil, records, nil)
+	require.NoError(t, err)
+	connector.SetFraudFeedbackService(service)
+
+	response, err := connector.ProcessPayment(ctx, testRiskRequest(RegionUSA, "USD", 50))
+	require.NoError(t, err)
+	require.Equal(t, TransactionStatusApproved, response.Status)
+
+	_, err = service.Submit(ctx, FraudFeedback{
+		TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelFraud, Source: FraudFeedbackSourceIssuer,
+	})
+	require.NoError(t, err)
+
+	next := testRiskRequest(RegionUSA, "USD", 50)
+	next.RequestID = "req-2"
+	next.TransactionID = "tx-2"
+	response, err = connector.ProcessPayment(ctx, next)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "risco_rejeitado", response.StatusCode)
+
+	record, err := records.GetTransactionRecord(ctx, "tenant-1", "tx-2")
+	require.NoError(t, err)
+	assert.Contains(t, record.RiskExplanation.RuleIDs(), "confirmed_fraud_history")
+}
+
+func TestFraudFeedbackHandler(t *testing.T) {
+	service, engine, _ := newTestFraudFeedbackService(t, nil)
+	evaluateTestFraudTransaction(t, service, engine, "tx-1")
+	router := mux.NewRouter()
+	NewFraudFeedbackHandler(service).RegisterRoutes(router)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"sem transação", `{"label": "fraud", "source": "issuer"}`, http.StatusBadRequest},
+		{"rótulo inválido", `{"transaction_id": "tx-1", "label": "talvez", "source": "issuer"}`, http.StatusBadRequest},
+		{"transação desconhecida", `{"transaction_id": "tx-9", "label": "fraud", "source": "issuer"}`, http.StatusNotFound},
+		{"rótulo registado", `{"transaction_id": "tx-1", "label": "fraud", "source": "issuer"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, serve(http.MethodPost, "/support/fraud-feedback", tt.body).Code)
+		})
+	}
+
+	rec := serve(http.MethodGet, "/support/fraud-feedback?merchant_id=merchant-1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list []FraudFeedback
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	require.Len(t, list, 1)
+	assert.Equal(t, FraudLabelFraud, list[0].Label)
+
+	rec = serve(http.MethodGet, "/support/merchants/merchant-1/risk-profile", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var profile MerchantRiskProfile
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&profile))
+	assert.Equal(t, int64(1), profile.ConfirmedFraud)
+
+	rec = serve(http.MethodGet, "/support/merchants/merchant-1/risk-adjustments", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func TestHTTPFraudScoringHook(t *testing.T) {
+	var received FraudTrainingSignal
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer chave", r.Header.Get("Authorization"))
+		assert.Equal(t, "fraud-label-tx-1-fraud", r.Header.Get("Idempotency-Key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	hook := NewHTTPFraudScoringHook(FraudFeedbackConfig{ScoringHookURL: server.URL, APIKey: "chave"})
+	signal := &FraudTrainingSignal{TenantID: "tenant-1", TransactionID: "tx-1", Label: FraudLabelFraud}
+	require.NoError(t, hook.RecordLabel(context.Background(), signal))
+	assert.Equal(t, "tx-1", received.TransactionID)
+
+	status = http.StatusBadRequest
+	err := hook.RecordLabel(context.Background(), signal)
+	assert.True(t, errors.Is(err, ErrFraudScoringHookUnavailable))
+}
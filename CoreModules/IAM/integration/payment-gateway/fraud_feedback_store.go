@@ -0,0 +1,139 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// FraudFeedbackStore define a persistência dos rótulos de fraude, dos perfis de risco dos
+// comerciantes e da auditoria dos seus ajustes
+type FraudFeedbackStore interface {
+	// SaveFraudFeedback grava o rótulo da transação, substituindo o anterior
+	SaveFraudFeedback(ctx context.Context, feedback *FraudFeedback) error
+
+	// GetFraudFeedback recupera o rótulo da transação do tenant; retorna ErrFraudFeedbackNotFound quando não existe
+	GetFraudFeedback(ctx context.Context, tenantID, transactionID string) (*FraudFeedback, error)
+
+	// ListFraudFeedback lista os rótulos do tenant por ordem de reporte, filtrados pelo comerciante quando indicado
+	ListFraudFeedback(ctx context.Context, tenantID, merchantID string) ([]*FraudFeedback, error)
+
+	// SaveMerchantRiskProfile grava o perfil de risco do comerciante, substituindo o anterior
+	SaveMerchantRiskProfile(ctx context.Context, profile *MerchantRiskProfile) error
+
+	// GetMerchantRiskProfile recupera o perfil de risco do comerciante do tenant; retorna
+	// ErrMerchantRiskProfileNotFound quando não existe
+	GetMerchantRiskProfile(ctx context.Context, tenantID, merchantID string) (*MerchantRiskProfile, error)
+
+	// SaveMerchantRiskAdjustment grava um ajuste do perfil de risco do comerciante
+	SaveMerchantRiskAdjustment(ctx context.Context, adjustment *MerchantRiskAdjustment) error
+
+	// ListMerchantRiskAdjustments lista os ajustes do comerciante do tenant por ordem de ajuste
+	ListMerchantRiskAdjustments(ctx context.Context, tenantID, merchantID string) ([]*MerchantRiskAdjustment, error)
+}
+
+// InMemoryFraudFeedbackStore armazena os rótulos, perfis e ajustes em memória
+type InMemoryFraudFeedbackStore struct {
+	feedback    map[string]*FraudFeedback       // Por tenant e transação
+	profiles    map[string]*MerchantRiskProfile // Por tenant e comerciante
+	adjustments []*MerchantRiskAdjustment       // Por ordem de ajuste
+	mutex       sync.RWMutex
+}
+
+// NewInMemoryFraudFeedbackStore cria um novo armazenamento em memória
+func NewInMemoryFraudFeedbackStore() *InMemoryFraudFeedbackStore {
+	return &InMemoryFraudFeedbackStore{
+		feedback: make(map[string]*FraudFeedback),
+		profiles: make(map[string]*MerchantRiskProfile),
+	}
+}
+
+// SaveFraudFeedback grava uma cópia do rótulo
+func (s *InMemoryFraudFeedbackStore) SaveFraudFeedback(ctx context.Context, feedback *FraudFeedback) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *feedback
+	s.feedback[feedback.TenantID+"/"+feedback.TransactionID] = &copied
+	return nil
+}
+
+// GetFraudFeedback retorna uma cópia do rótulo da transação do tenant
+func (s *InMemoryFraudFeedbackStore) GetFraudFeedback(ctx context.Context, tenantID, transactionID string) (*FraudFeedback, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	feedback, ok := s.feedback[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrFraudFeedbackNotFound
+	}
+	copied := *feedback
+	return &copied, nil
+}
+
+// ListFraudFeedback retorna cópias dos rótulos do tenant por ordem de reporte
+func (s *InMemoryFraudFeedbackStore) ListFraudFeedback(ctx context.Context, tenantID, merchantID string) ([]*FraudFeedback, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]*FraudFeedback, 0)
+	for _, feedback := range s.feedback {
+		if feedback.TenantID != tenantID || (merchantID != "" && feedback.MerchantID != merchantID) {
+			continue
+		}
+		copied := *feedback
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ReportedAt.Before(list[j].ReportedAt)
+	})
+	return list, nil
+}
+
+// SaveMerchantRiskProfile grava uma cópia do perfil
+func (s *InMemoryFraudFeedbackStore) SaveMerchantRiskProfile(ctx context.Context, profile *MerchantRiskProfile) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *profile
+	s.profiles[profile.TenantID+"/"+profile.MerchantID] = &copied
+	return nil
+}
+
+// GetMerchantRiskProfile retorna uma cópia do perfil do comerciante do tenant
+func (s *InMemoryFraudFeedbackStore) GetMerchantRiskProfile(ctx context.Context, tenantID, merchantID string) (*MerchantRiskProfile, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	profile, ok := s.profiles[tenantID+"/"+merchantID]
+	if !ok {
+		return nil, ErrMerchantRiskProfileNotFound
+	}
+	copied := *profile
+	return &copied, nil
+}
+
+// SaveMerchantRiskAdjustment grava uma cópia do ajuste
+func (s *InMemoryFraudFeedbackStore) SaveMerchantRiskAdjustment(ctx context.Context, adjustment *MerchantRiskAdjustment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *adjustment
+	s.adjustments = append(s.adjustments, &copied)
+	return nil
+}
+
+// ListMerchantRiskAdjustments retorna cópias dos ajustes do comerciante do tenant por ordem de ajuste
+func (s *InMemoryFraudFeedbackStore) ListMerchantRiskAdjustments(ctx context.Context, tenantID, merchantID string) ([]*MerchantRiskAdjustment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]*MerchantRiskAdjustment, 0)
+	for _, adjustment := range s.adjustments {
+		if adjustment.TenantID == tenantID && adjustment.MerchantID == merchantID {
+			copied := *adjustment
+			list = append(list, &copied)
+		}
+	}
+	return list, nil
+}
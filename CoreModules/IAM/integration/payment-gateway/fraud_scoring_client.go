@@ -0,0 +1,74 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// FraudScoringHook recebe os rótulos confirmados como sinais de treino do modelo de scoring
+type FraudScoringHook interface {
+	// RecordLabel entrega o exemplo rotulado; falhas retornam ErrFraudScoringHookUnavailable
+	RecordLabel(ctx context.Context, signal *FraudTrainingSignal) error
+}
+
+// HTTPFraudScoringHook envia os sinais de treino ao serviço de ML por HTTP/JSON
+type HTTPFraudScoringHook struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPFraudScoringHook cria o cliente do hook de scoring
+func NewHTTPFraudScoringHook(config FraudFeedbackConfig) *HTTPFraudScoringHook {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultFraudScoringHookTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPFraudScoringHook{
+		endpoint:   config.ScoringHookURL,
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// RecordLabel publica o sinal; a transação e o rótulo tornam o pedido repetível
+func (h *HTTPFraudScoringHook) RecordLabel(ctx context.Context, signal *FraudTrainingSignal) error {
+	payload, err := json.Marshal(signal)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar sinal de treino: %w", err)
+	}
+
+	resp, err := resilience.DoHTTP(ctx, h.httpClient, h.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant-ID", signal.TenantID)
+		req.Header.Set(resilience.IdempotencyKeyHeader, "fraud-label-"+signal.TransactionID+"-"+signal.Label)
+		if h.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+h.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFraudScoringHookUnavailable, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrFraudScoringHookUnavailable, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FraudVelocityStore conta as transações por usuário, dispositivo e comerciante num período móvel e
+// acumula os rótulos confirmados, que não expiram com o período
+type FraudVelocityStore interface {
+	// RecordTransaction contabiliza uma transação avaliada em cada uma das chaves
+	RecordTransaction(ctx context.Context, keys []string, at time.Time) error
+
+	// Label soma o rótulo confirmado às chaves, retirando o rótulo anterior quando a transação é reclassificada
+	Label(ctx context.Context, keys []string, label, previous string) error
+
+	// Stats retorna a atividade no período terminado em at e os rótulos da chave
+	Stats(ctx context.Context, key string, at time.Time) (VelocityStats, error)
+}
+
+// velocityKeys retorna as chaves de velocidade de uma transação do tenant
+func velocityKeys(tenantID, userID, deviceFingerprint, merchantID string) []string {
+	keys := make([]string, 0, 3)
+	if userID != "" {
+		keys = append(keys, tenantID+"/user:"+userID)
+	}
+	if deviceFingerprint != "" {
+		keys = append(keys, tenantID+"/device:"+deviceFingerprint)
+	}
+	if merchantID != "" {
+		keys = append(keys, tenantID+"/merchant:"+merchantID)
+	}
+	return keys
+}
+
+// velocityEntry guarda os instantes das transações recentes e os rótulos de uma chave
+type velocityEntry struct {
+	events []time.Time
+	fraud  int
+	legit  int
+}
+
+// InMemoryFraudVelocityStore mantém as contagens de velocidade em memória
+type InMemoryFraudVelocityStore struct {
+	window  time.Duration
+	entries map[string]*velocityEntry
+	mutex   sync.Mutex
+}
+
+// NewInMemoryFraudVelocityStore cria o store com o período indicado (padrão DefaultFraudVelocityWindow)
+func NewInMemoryFraudVelocityStore(window time.Duration) *InMemoryFraudVelocityStore {
+	if window <= 0 {
+		window = DefaultFraudVelocityWindow
+	}
+	return &InMemoryFraudVelocityStore{
+		window:  window,
+		entries: make(map[string]*velocityEntry),
+	}
+}
+
+// RecordTransaction contabiliza a transação e descarta os instantes fora do período
+func (s *InMemoryFraudVelocityStore) RecordTransaction(ctx context.Context, keys []string, at time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, key := range keys {
+		entry := s.entry(key)
+		entry.events = append(s.recent(entry, at), at)
+	}
+	return nil
+}
+
+// Label atualiza as contagens de rótulos das chaves
+func (s *InMemoryFraudVelocityStore) Label(ctx context.Context, keys []string, label, previous string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, key := range keys {
+		entry := s.entry(key)
+		switch previous {
+		case FraudLabelFraud:
+			entry.fraud--
+		case FraudLabelLegit:
+			entry.legit--
+		}
+		switch label {
+		case FraudLabelFraud:
+			entry.fraud++
+		case FraudLabelLegit:
+			entry.legit++
+		}
+	}
+	return nil
+}
+
+// Stats retorna a atividade recente e os rótulos da chave
+func (s *InMemoryFraudVelocityStore) Stats(ctx context.Context, key string, at time.Time) (VelocityStats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := VelocityStats{Key: key}
+	entry, exists := s.entries[key]
+	if !exists {
+		return stats, nil
+	}
+	entry.events = s.recent(entry, at)
+	stats.Transactions = len(entry.events)
+	stats.ConfirmedFraud = entry.fraud
+	stats.ConfirmedLegit = entry.legit
+	return stats, nil
+}
+
+// entry retorna a entrada da chave, criando-a quando ausente
+func (s *InMemoryFraudVelocityStore) entry(key string) *velocityEntry {
+	entry, exists := s.entries[key]
+	if !exists {
+		entry = &velocityEntry{}
+		s.entries[key] = entry
+	}
+	return entry
+}
+
+// recent descarta os instantes fora do período de velocidade
+func (s *InMemoryFraudVelocityStore) recent(entry *velocityEntry, at time.Time) []time.Time {
+	cutoff := at.Add(-s.window)
+	index := 0
+	for index < len(entry.events) && entry.events[index].Before(cutoff) {
+		index++
+	}
+	return entry.events[index:]
+}
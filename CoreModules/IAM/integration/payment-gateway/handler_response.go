@@ -0,0 +1,31 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// errorResponse representa uma resposta de erro padronizada dos handlers do gateway
+type errorResponse struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// respondWithJSON envia uma resposta JSON com o código HTTP e dados especificados
+func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if data != nil {
+		json.NewEncoder(w).Encode(data)
+	}
+}
+
+// respondWithError envia uma resposta de erro em formato JSON
+func respondWithError(w http.ResponseWriter, status int, code, message string) {
+	respondWithJSON(w, status, errorResponse{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	})
+}
@@ -0,0 +1,92 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// InstalmentCaptureClient captura as parcelas no meio de pagamento do plano, como transações
+// iniciadas pelo comerciante
+type InstalmentCaptureClient interface {
+	// Capture executa a captura e retorna a referência atribuída pelo PSP. Uma recusa do PSP
+	// envolve ErrInstalmentCaptureDeclined
+	Capture(ctx context.Context, charge InstalmentCharge) (string, error)
+}
+
+// HTTPInstalmentCaptureClient envia as capturas à API HTTP do PSP
+type HTTPInstalmentCaptureClient struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPInstalmentCaptureClient cria o cliente da API de captura do PSP
+func NewHTTPInstalmentCaptureClient(config InstalmentConfig) *HTTPInstalmentCaptureClient {
+	timeout := config.CaptureTimeout
+	if timeout <= 0 {
+		timeout = DefaultInstalmentCaptureTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPInstalmentCaptureClient{
+		endpoint:   config.CaptureEndpoint,
+		apiKey:     config.CaptureAPIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Capture envia um POST JSON com a captura ao PSP
+// A transação da captura é a chave de idempotência, para que as novas tentativas não dupliquem a cobrança
+func (c *HTTPInstalmentCaptureClient) Capture(ctx context.Context, charge InstalmentCharge) (string, error) {
+	payload, err := json.Marshal(struct {
+		InstalmentCharge
+		MerchantInitiated bool `json:"merchant_initiated"`
+	}{InstalmentCharge: charge, MerchantInitiated: true})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, charge.TransactionID)
+		if c.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("PSP indisponível para a captura: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("PSP indisponível para a captura: %w", err)
+	}
+	if resp.StatusCode == http.StatusPaymentRequired || resp.StatusCode == http.StatusUnprocessableEntity {
+		return "", fmt.Errorf("%w: HTTP %d", ErrInstalmentCaptureDeclined, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("PSP recusou a captura: HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		CaptureReference string `json:"capture_reference"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.CaptureReference == "" {
+		return "", fmt.Errorf("resposta inválida do PSP na captura")
+	}
+	return result.CaptureReference, nil
+}
@@ -0,0 +1,115 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// InstalmentHandler expõe às equipas de suporte os planos de parcelamento, o incumprimento dos
+// usuários e a liquidação antecipada
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type InstalmentHandler struct {
+	service *InstalmentService
+}
+
+// NewInstalmentHandler cria uma nova instância do InstalmentHandler
+func NewInstalmentHandler(service *InstalmentService) *InstalmentHandler {
+	return &InstalmentHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *InstalmentHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/instalments", h.ListPlans).Methods(http.MethodGet)
+	router.HandleFunc("/support/instalments/delinquency/{userId}", h.Delinquency).Methods(http.MethodGet)
+	router.HandleFunc("/support/instalments/{planId}", h.GetPlan).Methods(http.MethodGet)
+	router.HandleFunc("/support/instalments/{planId}/settlement", h.QuoteEarlySettlement).Methods(http.MethodGet)
+	router.HandleFunc("/support/instalments/{planId}/settlement", h.SettleEarly).Methods(http.MethodPost)
+}
+
+// ListPlans lista os planos do tenant filtrados por user_id e status
+func (h *InstalmentHandler) ListPlans(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	plans, err := h.service.ListPlans(r.Context(), InstalmentPlanFilter{
+		TenantID: r.Header.Get("X-Tenant-ID"),
+		UserID:   query.Get("user_id"),
+		Status:   query.Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar planos de parcelamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, plans)
+}
+
+// Delinquency resume os planos em incumprimento e as parcelas em atraso do usuário
+func (h *InstalmentHandler) Delinquency(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.Delinquency(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["userId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao calcular incumprimento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// GetPlan retorna as parcelas e o estado de um plano do tenant
+func (h *InstalmentHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.service.GetPlan(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["planId"])
+	if err != nil {
+		h.respondWithPlanError(w, err, "Erro ao recuperar plano de parcelamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, plan)
+}
+
+// QuoteEarlySettlement simula a liquidação antecipada do plano
+func (h *InstalmentHandler) QuoteEarlySettlement(w http.ResponseWriter, r *http.Request) {
+	settlement, err := h.service.QuoteEarlySettlement(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["planId"])
+	if err != nil {
+		h.respondWithPlanError(w, err, "Erro ao simular liquidação antecipada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, settlement)
+}
+
+// SettleEarly captura a liquidação antecipada do plano a pedido do cliente
+func (h *InstalmentHandler) SettleEarly(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get("X-Tenant-ID")
+	planID := mux.Vars(r)["planId"]
+
+	plan, err := h.service.SettleEarly(r.Context(), tenantID, planID)
+	if err != nil {
+		h.respondWithPlanError(w, err, "Erro ao liquidar plano de parcelamento")
+		return
+	}
+
+	// Registar a liquidação para auditoria das ações das equipas de suporte
+	h.service.logger.InfoWithContext(r.Context(), "Plano de parcelamento liquidado antecipadamente pelo suporte",
+		"tenant_id", tenantID,
+		"plan_id", planID,
+		"amount", plan.EarlySettlement.Amount,
+		"operator", supportOperator(r))
+
+	respondWithJSON(w, http.StatusOK, plan)
+}
+
+// respondWithPlanError traduz os erros do serviço de parcelamento em respostas HTTP
+func (h *InstalmentHandler) respondWithPlanError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrInstalmentPlanNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Plano de parcelamento não encontrado")
+	case errors.Is(err, ErrInstalmentPlanClosed):
+		respondWithError(w, http.StatusConflict, "plan_closed", "Plano de parcelamento encerrado")
+	case errors.Is(err, ErrInstalmentCaptureInFlight):
+		respondWithError(w, http.StatusConflict, "capture_in_flight", "Captura em curso no plano de parcelamento")
+	case errors.Is(err, ErrInstalmentCaptureDeclined):
+		respondWithError(w, http.StatusPaymentRequired, "capture_declined", "Captura da liquidação antecipada recusada")
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
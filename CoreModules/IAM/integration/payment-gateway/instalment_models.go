@@ -0,0 +1,177 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estados de um plano de parcelamento
+const (
+	InstalmentPlanActive     = "active"
+	InstalmentPlanDelinquent = "delinquent" // Parcela em atraso além do período de tolerância do mercado
+	InstalmentPlanSettled    = "settled"    // Todas as parcelas pagas, incluindo por liquidação antecipada
+	InstalmentPlanCancelled  = "cancelled"  // Captura da primeira parcela recusada na compra
+)
+
+// Estados de uma parcela
+const (
+	InstalmentStatusScheduled    = "scheduled"
+	InstalmentStatusPaid         = "paid"
+	InstalmentStatusOverdue      = "overdue"       // Captura recusada; nova tentativa após RetryInterval
+	InstalmentStatusSettledEarly = "settled_early" // Paga na liquidação antecipada do plano
+)
+
+// Valores padrão do fluxo de parcelamento
+const (
+	DefaultInstalmentCaptureInterval = 15 * time.Minute // Periodicidade da captura das parcelas vencidas
+	DefaultInstalmentRetryInterval   = 24 * time.Hour   // Intervalo entre tentativas de captura de uma parcela em atraso
+	DefaultInstalmentCaptureTimeout  = 30 * time.Second
+)
+
+// Erros do fluxo de parcelamento. As recusas na criação do plano envolvem ErrInstalmentRejected
+var (
+	ErrInstalmentRejected          = errors.New("parcelamento recusado")
+	ErrInstalmentMarketUnsupported = errors.New("parcelamento não disponível no mercado da transação")
+	ErrInstalmentCountInvalid      = errors.New("número de parcelas fora dos limites do mercado")
+	ErrInstalmentRateInvalid       = errors.New("taxa de juro mensal fora dos limites do mercado")
+	ErrInstalmentInterestRequired  = errors.New("número de parcelas acima do limite sem juros do mercado")
+	ErrInstalmentAmountTooLow      = errors.New("valor da parcela abaixo do mínimo do mercado")
+	ErrInstalmentFundingInvalid    = errors.New("meio de pagamento das parcelas inválido")
+	ErrInstalmentCaptureDeclined   = errors.New("captura da parcela recusada")
+	ErrInstalmentPlanNotFound      = errors.New("plano de parcelamento não encontrado")
+	ErrInstalmentPlanClosed        = errors.New("plano de parcelamento encerrado")
+	ErrInstalmentCaptureInFlight   = errors.New("captura em curso no plano de parcelamento")
+)
+
+// InstalmentMarketRule define os limites do parcelamento num mercado
+type InstalmentMarketRule struct {
+	Market                          string  `json:"market"`
+	MaxInstalments                  int     `json:"max_instalments"`
+	MinInstalmentAmount             float64 `json:"min_instalment_amount"`                // Na moeda do mercado
+	InterestFreeMax                 int     `json:"interest_free_max"`                    // Parcelas sem juros, a cargo do comerciante
+	MaxMonthlyRate                  float64 `json:"max_monthly_rate"`                     // Taxa de juro mensal máxima cobrada ao cliente
+	EarlySettlementFeeRate          float64 `json:"early_settlement_fee_rate"`            // Comissão sobre o capital antecipado
+	EarlySettlementFeeRateFinalYear float64 `json:"early_settlement_fee_rate_final_year"` // Comissão com 12 parcelas ou menos por vencer
+	GraceDays                       int     `json:"grace_days"`                           // Dias de atraso até o plano entrar em incumprimento
+}
+
+// DefaultInstalmentMarketRules retorna as regras de parcelamento por mercado. No Brasil o parcelado
+// sem juros vai até 12 vezes e a liquidação antecipada apenas reduz os juros (CDC, art. 52, §2º);
+// na UE a comissão de reembolso antecipado é de 1%, ou 0,5% no último ano (Diretiva 2008/48/CE, art. 16)
+func DefaultInstalmentMarketRules() map[string]InstalmentMarketRule {
+	rules := []InstalmentMarketRule{
+		{Market: RegionBrazil, MaxInstalments: 12, MinInstalmentAmount: 5, InterestFreeMax: 12,
+			MaxMonthlyRate: 0.0999, GraceDays: 15},
+		{Market: RegionEU, MaxInstalments: 24, MinInstalmentAmount: 20, InterestFreeMax: 3,
+			MaxMonthlyRate: 0.02, EarlySettlementFeeRate: 0.01, EarlySettlementFeeRateFinalYear: 0.005, GraceDays: 30},
+		{Market: RegionAngola, MaxInstalments: 6, MinInstalmentAmount: 5000, MaxMonthlyRate: 0.04, GraceDays: 30},
+		{Market: RegionMozambique, MaxInstalments: 6, MinInstalmentAmount: 500, MaxMonthlyRate: 0.04, GraceDays: 30},
+		{Market: RegionUSA, MaxInstalments: 24, MinInstalmentAmount: 25, InterestFreeMax: 4,
+			MaxMonthlyRate: 0.03, GraceDays: 30},
+		{Market: RegionGlobal, MaxInstalments: 12, MinInstalmentAmount: 10, MaxMonthlyRate: 0.03, GraceDays: 30},
+	}
+
+	byMarket := make(map[string]InstalmentMarketRule, len(rules))
+	for _, rule := range rules {
+		byMarket[rule.Market] = rule
+	}
+	return byMarket
+}
+
+// InstalmentConfig contém configurações do serviço de parcelamento
+type InstalmentConfig struct {
+	MarketRules     map[string]InstalmentMarketRule `json:"market_rules"`     // Padrão: DefaultInstalmentMarketRules
+	CaptureInterval time.Duration                   `json:"capture_interval"` // Periodicidade da captura das parcelas vencidas
+	RetryInterval   time.Duration                   `json:"retry_interval"`   // Espera entre tentativas de uma parcela em atraso
+	CaptureEndpoint string                          `json:"capture_endpoint"` // API de captura do PSP
+	CaptureAPIKey   string                          `json:"-"`
+	CaptureTimeout  time.Duration                   `json:"capture_timeout"`
+	Resilience      resilience.Policy               `json:"resilience"`
+}
+
+// Instalment é uma parcela do plano
+type Instalment struct {
+	Number        int        `json:"number"`
+	DueDate       time.Time  `json:"due_date"`
+	Principal     float64    `json:"principal"`
+	Interest      float64    `json:"interest"`
+	Amount        float64    `json:"amount"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	Reference     string     `json:"reference,omitempty"` // Referência da captura no PSP
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // Próxima tentativa de uma parcela em atraso
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+}
+
+// InstalmentEarlySettlement é a liquidação antecipada das parcelas por vencer: o capital em dívida,
+// as parcelas já vencidas e a comissão do mercado, sem os juros dos períodos seguintes
+type InstalmentEarlySettlement struct {
+	OverdueAmount  float64    `json:"overdue_amount"`  // Parcelas vencidas e não pagas, com juros
+	Principal      float64    `json:"principal"`       // Capital das parcelas por vencer
+	InterestWaived float64    `json:"interest_waived"` // Juros das parcelas por vencer dispensados
+	Fee            float64    `json:"fee"`
+	Amount         float64    `json:"amount"`
+	Reference      string     `json:"reference,omitempty"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+}
+
+// InstalmentPlan é o plano de parcelamento de uma compra (tabela Price, primeira parcela na compra)
+type InstalmentPlan struct {
+	ID              string                     `json:"id" db:"id"`
+	TenantID        string                     `json:"tenant_id" db:"tenant_id"`
+	TransactionID   string                     `json:"transaction_id" db:"transaction_id"`
+	MerchantID      string                     `json:"merchant_id" db:"merchant_id"`
+	UserID          string                     `json:"user_id" db:"user_id"`
+	RegionCode      string                     `json:"region_code" db:"region_code"`
+	FundingMethod   string                     `json:"funding_method" db:"funding_method"` // Meio de pagamento em que as parcelas são capturadas
+	CardTokenID     string                     `json:"card_token_id,omitempty" db:"card_token_id"`
+	Principal       float64                    `json:"principal" db:"principal"`
+	Currency        string                     `json:"currency" db:"currency"`
+	Count           int                        `json:"count" db:"instalment_count"`
+	InterestFree    bool                       `json:"interest_free" db:"interest_free"`
+	MonthlyRate     float64                    `json:"monthly_rate" db:"monthly_rate"`
+	AnnualRate      float64                    `json:"annual_rate" db:"annual_rate"` // Taxa anual equivalente, divulgada como CET (Brasil) ou TAEG (UE)
+	TotalInterest   float64                    `json:"total_interest" db:"total_interest"`
+	TotalAmount     float64                    `json:"total_amount" db:"total_amount"`
+	Status          string                     `json:"status" db:"status"`
+	Instalments     []Instalment               `json:"instalments" db:"-"`
+	EarlySettlement *InstalmentEarlySettlement `json:"early_settlement,omitempty" db:"-"`
+	CreatedAt       time.Time                  `json:"created_at" db:"created_at"`
+	DelinquentSince *time.Time                 `json:"delinquent_since,omitempty" db:"delinquent_since"`
+}
+
+// InstalmentPlanFilter restringe a listagem dos planos; campos vazios não filtram
+type InstalmentPlanFilter struct {
+	TenantID string
+	UserID   string
+	Status   string
+	OpenOnly bool // Apenas planos ativos ou em incumprimento
+}
+
+// InstalmentCharge é uma captura reservada num plano: uma parcela ou, com Number 0, a liquidação antecipada
+type InstalmentCharge struct {
+	PlanID        string                     `json:"plan_id"`
+	TenantID      string                     `json:"tenant_id"`
+	TransactionID string                     `json:"transaction_id"` // Transação da captura, derivada da compra
+	MerchantID    string                     `json:"merchant_id"`
+	UserID        string                     `json:"user_id"`
+	RegionCode    string                     `json:"region_code"`
+	FundingMethod string                     `json:"funding_method"`
+	CardTokenID   string                     `json:"card_token_id,omitempty"`
+	Number        int                        `json:"number"`
+	Amount        float64                    `json:"amount"`
+	Currency      string                     `json:"currency"`
+	Description   string                     `json:"description"`
+	Settlement    *InstalmentEarlySettlement `json:"-"`
+}
+
+// InstalmentDelinquency resume o incumprimento dos planos de um usuário para o motor de risco
+type InstalmentDelinquency struct {
+	UserID             string  `json:"user_id"`
+	DelinquentPlans    int     `json:"delinquent_plans"`
+	OverdueInstalments int     `json:"overdue_instalments"`
+	OverdueAmount      float64 `json:"overdue_amount"`
+}
@@ -0,0 +1,138 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresInstalmentStore implementa InstalmentStore para PostgreSQL
+type PostgresInstalmentStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresInstalmentStore cria uma nova instância de PostgresInstalmentStore
+func NewPostgresInstalmentStore(db *sqlx.DB) *PostgresInstalmentStore {
+	return &PostgresInstalmentStore{db: db}
+}
+
+// dbInstalmentPlan é a representação de InstalmentPlan na base de dados
+type dbInstalmentPlan struct {
+	InstalmentPlan
+	InstalmentsJSON     []byte `db:"instalments"`
+	EarlySettlementJSON []byte `db:"early_settlement"`
+}
+
+// instalmentPlanColumns são as colunas lidas nas consultas de planos
+const instalmentPlanColumns = `id, tenant_id, transaction_id, merchant_id, user_id, region_code, funding_method,
+	card_token_id, principal, currency, instalment_count, interest_free, monthly_rate, annual_rate, total_interest,
+	total_amount, status, instalments, early_settlement, created_at, delinquent_since`
+
+// SaveInstalmentPlan grava o plano, substituindo o estado anterior
+func (r *PostgresInstalmentStore) SaveInstalmentPlan(ctx context.Context, plan *InstalmentPlan) error {
+	row := &dbInstalmentPlan{InstalmentPlan: *plan}
+
+	var err error
+	if row.InstalmentsJSON, err = json.Marshal(plan.Instalments); err != nil {
+		return fmt.Errorf("falha ao codificar parcelas: %w", err)
+	}
+	if row.EarlySettlementJSON, err = json.Marshal(plan.EarlySettlement); err != nil {
+		return fmt.Errorf("falha ao codificar liquidação antecipada: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.instalment_plans (
+			id, tenant_id, transaction_id, merchant_id, user_id, region_code, funding_method,
+			card_token_id, principal, currency, instalment_count, interest_free, monthly_rate, annual_rate, total_interest,
+			total_amount, status, instalments, early_settlement, created_at, delinquent_since
+		) VALUES (
+			:id, :tenant_id, :transaction_id, :merchant_id, :user_id, :region_code, :funding_method,
+			:card_token_id, :principal, :currency, :instalment_count, :interest_free, :monthly_rate, :annual_rate, :total_interest,
+			:total_amount, :status, :instalments, :early_settlement, :created_at, :delinquent_since
+		)
+		ON CONFLICT (tenant_id, id) DO UPDATE SET
+			status = EXCLUDED.status,
+			instalments = EXCLUDED.instalments,
+			early_settlement = EXCLUDED.early_settlement,
+			delinquent_since = EXCLUDED.delinquent_since
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar plano de parcelamento: %w", err)
+	}
+
+	return nil
+}
+
+// GetInstalmentPlan recupera o plano do tenant
+func (r *PostgresInstalmentStore) GetInstalmentPlan(ctx context.Context, tenantID, planID string) (*InstalmentPlan, error) {
+	var row dbInstalmentPlan
+	query := `SELECT ` + instalmentPlanColumns + ` FROM payment_gateway.instalment_plans
+		WHERE tenant_id = $1 AND id = $2`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, planID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInstalmentPlanNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar plano de parcelamento: %w", err)
+	}
+	return row.decode()
+}
+
+// ListInstalmentPlans lista os planos do filtro por ordem de criação
+func (r *PostgresInstalmentStore) ListInstalmentPlans(ctx context.Context, filter InstalmentPlanFilter) ([]*InstalmentPlan, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", column, len(args)))
+	}
+	if filter.TenantID != "" {
+		addCondition("tenant_id =", filter.TenantID)
+	}
+	if filter.UserID != "" {
+		addCondition("user_id =", filter.UserID)
+	}
+	if filter.Status != "" {
+		addCondition("status =", filter.Status)
+	}
+	if filter.OpenOnly {
+		conditions = append(conditions, "status IN ('active', 'delinquent')")
+	}
+
+	var rows []dbInstalmentPlan
+	query := `SELECT ` + instalmentPlanColumns + ` FROM payment_gateway.instalment_plans
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao listar planos de parcelamento: %w", err)
+	}
+
+	plans := make([]*InstalmentPlan, 0, len(rows))
+	for i := range rows {
+		plan, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// decode descodifica as parcelas e a liquidação antecipada gravadas em JSONB
+func (row *dbInstalmentPlan) decode() (*InstalmentPlan, error) {
+	plan := row.InstalmentPlan
+	if err := json.Unmarshal(row.InstalmentsJSON, &plan.Instalments); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar parcelas: %w", err)
+	}
+	if len(row.EarlySettlementJSON) > 0 {
+		if err := json.Unmarshal(row.EarlySettlementJSON, &plan.EarlySettlement); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar liquidação antecipada: %w", err)
+		}
+	}
+	return &plan, nil
+}
@@ -0,0 +1,80 @@
+package paymentgateway
+
+import (
+	"math"
+	"time"
+)
+
+// buildInstalmentSchedule calcula as parcelas mensais pela tabela Price em série antecipada: a primeira
+// parcela vence na compra e não tem juros. A última parcela absorve os arredondamentos do capital
+func buildInstalmentSchedule(principal, monthlyRate float64, count int, start time.Time) []Instalment {
+	payment := principal / float64(count)
+	if monthlyRate > 0 {
+		payment = principal * monthlyRate / ((1 - math.Pow(1+monthlyRate, -float64(count))) * (1 + monthlyRate))
+	}
+	payment = roundAmount(payment)
+
+	instalments := make([]Instalment, count)
+	balance := roundAmount(principal)
+	for i := range instalments {
+		interest := 0.0
+		if i > 0 {
+			interest = roundAmount(balance * monthlyRate)
+		}
+		amortization := roundAmount(payment - interest)
+		if i == count-1 {
+			amortization = balance
+		}
+		balance = roundAmount(balance - amortization)
+
+		instalments[i] = Instalment{
+			Number:    i + 1,
+			DueDate:   addInstalmentMonths(start, i),
+			Principal: amortization,
+			Interest:  interest,
+			Amount:    roundAmount(amortization + interest),
+			Status:    InstalmentStatusScheduled,
+		}
+	}
+	return instalments
+}
+
+// annualEquivalentRate converte a taxa mensal na taxa anual equivalente, com quatro casas decimais
+func annualEquivalentRate(monthlyRate float64) float64 {
+	return math.Round((math.Pow(1+monthlyRate, 12)-1)*10000) / 10000
+}
+
+// addInstalmentMonths soma meses à data mantendo o dia, limitado ao último dia do mês de destino
+func addInstalmentMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	if lastDay := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, t.Location()).Day(); day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month+time.Month(months), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// firstUnpaidInstalment retorna a parcela mais antiga por pagar do plano
+func firstUnpaidInstalment(plan *InstalmentPlan) *Instalment {
+	for i := range plan.Instalments {
+		switch plan.Instalments[i].Status {
+		case InstalmentStatusScheduled, InstalmentStatusOverdue:
+			return &plan.Instalments[i]
+		}
+	}
+	return nil
+}
+
+// instalmentPlanOpen indica se o plano ainda tem parcelas a capturar
+func instalmentPlanOpen(plan *InstalmentPlan) bool {
+	return plan.Status == InstalmentPlanActive || plan.Status == InstalmentPlanDelinquent
+}
+
+// instalmentPlanOverdue indica se o plano tem parcelas em atraso
+func instalmentPlanOverdue(plan *InstalmentPlan) bool {
+	for _, instalment := range plan.Instalments {
+		if instalment.Status == InstalmentStatusOverdue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,641 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// InstalmentService cria os planos de parcelamento das compras, captura as parcelas vencidas em
+// segundo plano e acompanha o incumprimento dos usuários
+type InstalmentService struct {
+	config   InstalmentConfig
+	store    InstalmentStore
+	client   InstalmentCaptureClient
+	webhooks *WebhookDeliveryService
+
+	// Serializa as reservas de captura; cada plano tem no máximo uma captura em curso. Entre réplicas, a
+	// chave de idempotência da captura impede que a mesma parcela seja cobrada duas vezes
+	mutex     sync.Mutex
+	capturing map[string]bool
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewInstalmentService cria o serviço de parcelamento. Sem cliente é usada a API HTTP de captura do PSP
+func NewInstalmentService(config InstalmentConfig, store InstalmentStore, client InstalmentCaptureClient) (*InstalmentService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-instalments",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if len(config.MarketRules) == 0 {
+		config.MarketRules = DefaultInstalmentMarketRules()
+	}
+	if config.CaptureInterval <= 0 {
+		config.CaptureInterval = DefaultInstalmentCaptureInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultInstalmentRetryInterval
+	}
+	if client == nil {
+		client = NewHTTPInstalmentCaptureClient(config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &InstalmentService{
+		config:          config,
+		store:           store,
+		client:          client,
+		capturing:       make(map[string]bool),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes das parcelas capturadas e recusadas
+func (s *InstalmentService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.webhooks = webhooks
+}
+
+// Start inicia a captura periódica das parcelas vencidas
+func (s *InstalmentService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.CaptureInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.CaptureDue(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Captura de parcelas iniciada", "interval", s.config.CaptureInterval.String())
+}
+
+// Stop interrompe a captura periódica
+func (s *InstalmentService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// CreatePlan cria o plano da compra a partir de PaymentDetails["instalments"], da taxa de juro mensal em
+// PaymentDetails["monthly_interest_rate"] (ausente: parcelado sem juros) e do meio de pagamento em
+// PaymentDetails["funding_method"] (padrão: cartão). Retorna o plano e a captura reservada da primeira
+// parcela; as recusas envolvem ErrInstalmentRejected
+func (s *InstalmentService) CreatePlan(ctx context.Context, req *PaymentRequest) (*InstalmentPlan, InstalmentCharge, error) {
+	rule, err := s.validate(req)
+	if err != nil {
+		return nil, InstalmentCharge{}, fmt.Errorf("%w: %w", ErrInstalmentRejected, err)
+	}
+
+	n, _ := req.PaymentDetails["instalments"].(float64)
+	count := int(n)
+	rate, _ := req.PaymentDetails["monthly_interest_rate"].(float64)
+	fundingMethod, _ := req.PaymentDetails["funding_method"].(string)
+	if fundingMethod == "" {
+		fundingMethod = PaymentMethodCard
+	}
+
+	now := s.now().UTC()
+	instalments := buildInstalmentSchedule(req.Amount, rate, count, now)
+	if instalments[0].Amount < rule.MinInstalmentAmount {
+		return nil, InstalmentCharge{}, fmt.Errorf("%w: %w: %.2f %s (mínimo %.2f)", ErrInstalmentRejected,
+			ErrInstalmentAmountTooLow, instalments[0].Amount, req.Currency, rule.MinInstalmentAmount)
+	}
+
+	plan := &InstalmentPlan{
+		ID:            uuid.New().String(),
+		TenantID:      req.TenantID,
+		TransactionID: req.TransactionID,
+		MerchantID:    req.MerchantID,
+		UserID:        req.UserID,
+		RegionCode:    req.RegionCode,
+		FundingMethod: fundingMethod,
+		CardTokenID:   req.CardTokenID,
+		Principal:     roundAmount(req.Amount),
+		Currency:      req.Currency,
+		Count:         count,
+		InterestFree:  rate == 0,
+		MonthlyRate:   rate,
+		AnnualRate:    annualEquivalentRate(rate),
+		Status:        InstalmentPlanActive,
+		Instalments:   instalments,
+		CreatedAt:     now,
+	}
+	for _, instalment := range instalments {
+		plan.TotalInterest += instalment.Interest
+		plan.TotalAmount += instalment.Amount
+	}
+	plan.TotalInterest = roundAmount(plan.TotalInterest)
+	plan.TotalAmount = roundAmount(plan.TotalAmount)
+
+	// A primeira parcela é capturada na compra; o plano fica reservado até ao resultado
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.store.SaveInstalmentPlan(ctx, plan); err != nil {
+		return nil, InstalmentCharge{}, err
+	}
+	s.capturing[instalmentPlanKey(plan.TenantID, plan.ID)] = true
+	return plan, instalmentCharge(plan, &plan.Instalments[0]), nil
+}
+
+// Execute cria o plano da compra e captura a primeira parcela. Retorna o plano e a referência da
+// captura; uma recusa do PSP envolve ErrInstalmentCaptureDeclined e cancela o plano
+func (s *InstalmentService) Execute(ctx context.Context, req *PaymentRequest) (*InstalmentPlan, string, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "InstalmentService.Execute")
+	defer span.End()
+
+	plan, charge, err := s.CreatePlan(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		s.metricsRecorder.CounterInc("payment_instalment_plans_total", map[string]string{
+			"market": req.RegionCode,
+			"status": "rejected",
+		})
+		return nil, "", err
+	}
+
+	// Evento de auditoria da criação do plano, com as condições divulgadas ao cliente
+	s.logger.InfoWithContext(ctx, "Plano de parcelamento criado",
+		"tenant_id", plan.TenantID,
+		"plan_id", plan.ID,
+		"transaction_id", plan.TransactionID,
+		"user_id", plan.UserID,
+		"count", plan.Count,
+		"instalment_amount", plan.Instalments[0].Amount,
+		"monthly_rate", plan.MonthlyRate,
+		"annual_rate", plan.AnnualRate,
+		"total_amount", plan.TotalAmount)
+	s.metricsRecorder.CounterInc("payment_instalment_plans_total", map[string]string{
+		"market": plan.RegionCode,
+		"status": "created",
+	})
+
+	plan, reference, err := s.capture(ctx, charge)
+	if err != nil {
+		span.RecordError(err)
+		return plan, "", fmt.Errorf("captura da primeira parcela recusada: %w", err)
+	}
+	return plan, reference, nil
+}
+
+// GetPlan retorna o plano de parcelamento do tenant
+func (s *InstalmentService) GetPlan(ctx context.Context, tenantID, planID string) (*InstalmentPlan, error) {
+	return s.store.GetInstalmentPlan(ctx, tenantID, planID)
+}
+
+// ListPlans lista os planos do filtro por ordem de criação
+func (s *InstalmentService) ListPlans(ctx context.Context, filter InstalmentPlanFilter) ([]*InstalmentPlan, error) {
+	return s.store.ListInstalmentPlans(ctx, filter)
+}
+
+// Delinquency resume os planos em incumprimento e as parcelas em atraso do usuário
+func (s *InstalmentService) Delinquency(ctx context.Context, tenantID, userID string) (*InstalmentDelinquency, error) {
+	plans, err := s.store.ListInstalmentPlans(ctx, InstalmentPlanFilter{TenantID: tenantID, UserID: userID, OpenOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &InstalmentDelinquency{UserID: userID}
+	for _, plan := range plans {
+		if plan.Status == InstalmentPlanDelinquent {
+			summary.DelinquentPlans++
+		}
+		for _, instalment := range plan.Instalments {
+			if instalment.Status == InstalmentStatusOverdue {
+				summary.OverdueInstalments++
+				summary.OverdueAmount += instalment.Amount
+			}
+		}
+	}
+	summary.OverdueAmount = roundAmount(summary.OverdueAmount)
+	return summary, nil
+}
+
+// QuoteEarlySettlement calcula o valor da liquidação antecipada do plano
+func (s *InstalmentService) QuoteEarlySettlement(ctx context.Context, tenantID, planID string) (*InstalmentEarlySettlement, error) {
+	plan, err := s.store.GetInstalmentPlan(ctx, tenantID, planID)
+	if err != nil {
+		return nil, err
+	}
+	if !instalmentPlanOpen(plan) {
+		return nil, ErrInstalmentPlanClosed
+	}
+	return s.earlySettlement(plan), nil
+}
+
+// SettleEarly captura a liquidação antecipada do plano e marca as parcelas por pagar como liquidadas
+func (s *InstalmentService) SettleEarly(ctx context.Context, tenantID, planID string) (*InstalmentPlan, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "InstalmentService.SettleEarly")
+	defer span.End()
+
+	charge, err := s.claimEarlySettlement(ctx, tenantID, planID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	plan, _, err := s.capture(ctx, charge)
+	if err != nil {
+		span.RecordError(err)
+		return plan, err
+	}
+	return plan, nil
+}
+
+// CaptureDue captura a parcela mais antiga por pagar de cada plano, quando vencida ou, se em atraso,
+// quando chegou a hora da nova tentativa, e marca em incumprimento os planos fora da tolerância
+func (s *InstalmentService) CaptureDue(ctx context.Context) {
+	charges, err := s.claimDue(ctx)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao reservar parcelas vencidas", "error", err.Error())
+		return
+	}
+
+	for _, charge := range charges {
+		plan, reference, err := s.capture(ctx, charge)
+		if plan == nil {
+			continue
+		}
+		if err != nil {
+			s.notify(ctx, plan, charge, WebhookEventTransactionFailed, "", err.Error())
+			continue
+		}
+		s.notify(ctx, plan, charge, WebhookEventTransactionCompleted, reference, "")
+	}
+
+	delinquent, err := s.markDelinquent(ctx)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao marcar planos em incumprimento", "error", err.Error())
+		return
+	}
+	for _, plan := range delinquent {
+		// Evento de segurança: o usuário deixa de ter novas compras parceladas aprovadas
+		s.logger.WarnWithContext(ctx, "Plano de parcelamento em incumprimento",
+			"tenant_id", plan.TenantID,
+			"plan_id", plan.ID,
+			"transaction_id", plan.TransactionID,
+			"user_id", plan.UserID)
+		s.metricsRecorder.CounterInc("payment_instalment_delinquencies_total", map[string]string{
+			"market": plan.RegionCode,
+		})
+	}
+}
+
+// validate verifica o número de parcelas, a taxa de juro e o meio de pagamento face às regras do mercado
+func (s *InstalmentService) validate(req *PaymentRequest) (InstalmentMarketRule, error) {
+	rule, ok := s.config.MarketRules[req.RegionCode]
+	if !ok {
+		return rule, ErrInstalmentMarketUnsupported
+	}
+
+	count := 0
+	if n, ok := req.PaymentDetails["instalments"].(float64); ok {
+		count = int(n)
+	}
+	if count < 2 || count > rule.MaxInstalments {
+		return rule, fmt.Errorf("%w: %d (permitido de 2 a %d)", ErrInstalmentCountInvalid, count, rule.MaxInstalments)
+	}
+
+	rate, _ := req.PaymentDetails["monthly_interest_rate"].(float64)
+	if rate < 0 || rate > rule.MaxMonthlyRate {
+		return rule, fmt.Errorf("%w: %.4f (máximo %.4f)", ErrInstalmentRateInvalid, rate, rule.MaxMonthlyRate)
+	}
+	if rate == 0 && count > rule.InterestFreeMax {
+		return rule, fmt.Errorf("%w: %d (máximo %d sem juros)", ErrInstalmentInterestRequired, count, rule.InterestFreeMax)
+	}
+
+	fundingMethod, _ := req.PaymentDetails["funding_method"].(string)
+	if fundingMethod == PaymentMethodInstalment || fundingMethod == PaymentMethodRefund {
+		return rule, fmt.Errorf("%w: %s", ErrInstalmentFundingInvalid, fundingMethod)
+	}
+	return rule, nil
+}
+
+// claimDue reserva a captura da parcela mais antiga por pagar de cada plano vencido. As parcelas de
+// um plano são capturadas por ordem
+func (s *InstalmentService) claimDue(ctx context.Context) ([]InstalmentCharge, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	plans, err := s.store.ListInstalmentPlans(ctx, InstalmentPlanFilter{OpenOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	var charges []InstalmentCharge
+	for _, plan := range plans {
+		key := instalmentPlanKey(plan.TenantID, plan.ID)
+		if s.capturing[key] {
+			continue
+		}
+		instalment := firstUnpaidInstalment(plan)
+		if instalment == nil {
+			continue
+		}
+		if instalment.DueDate.After(now) || (instalment.NextAttemptAt != nil && instalment.NextAttemptAt.After(now)) {
+			continue
+		}
+		s.capturing[key] = true
+		charges = append(charges, instalmentCharge(plan, instalment))
+	}
+	return charges, nil
+}
+
+// claimEarlySettlement reserva a captura da liquidação antecipada do plano
+func (s *InstalmentService) claimEarlySettlement(ctx context.Context, tenantID, planID string) (InstalmentCharge, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	plan, err := s.store.GetInstalmentPlan(ctx, tenantID, planID)
+	if err != nil {
+		return InstalmentCharge{}, err
+	}
+	if !instalmentPlanOpen(plan) {
+		return InstalmentCharge{}, ErrInstalmentPlanClosed
+	}
+	key := instalmentPlanKey(tenantID, planID)
+	if s.capturing[key] {
+		return InstalmentCharge{}, ErrInstalmentCaptureInFlight
+	}
+
+	settlement := s.earlySettlement(plan)
+	s.capturing[key] = true
+	charge := instalmentCharge(plan, nil)
+	charge.Amount = settlement.Amount
+	charge.Settlement = settlement
+	return charge, nil
+}
+
+// capture executa a captura reservada no PSP e regista o resultado no plano
+func (s *InstalmentService) capture(ctx context.Context, charge InstalmentCharge) (*InstalmentPlan, string, error) {
+	reference, captureErr := s.client.Capture(ctx, charge)
+	plan, err := s.recordCapture(ctx, charge, reference, captureErr)
+	if err != nil {
+		// A captura pode ter sido concluída no PSP sem ficar registada; a nova tentativa usa a mesma chave
+		s.logger.ErrorWithContext(ctx, "Falha ao registar captura de parcela",
+			"tenant_id", charge.TenantID,
+			"plan_id", charge.PlanID,
+			"transaction_id", charge.TransactionID,
+			"error", err.Error())
+		return nil, "", err
+	}
+
+	outcome := "paid"
+	if captureErr != nil {
+		outcome = "failed"
+		s.logger.WarnWithContext(ctx, "Captura de parcela recusada",
+			"tenant_id", charge.TenantID,
+			"plan_id", charge.PlanID,
+			"transaction_id", charge.TransactionID,
+			"instalment", charge.Number,
+			"error", captureErr.Error())
+	} else {
+		// Evento de auditoria da captura
+		s.logger.InfoWithContext(ctx, "Parcela capturada",
+			"tenant_id", charge.TenantID,
+			"plan_id", charge.PlanID,
+			"transaction_id", charge.TransactionID,
+			"instalment", charge.Number,
+			"amount", charge.Amount,
+			"currency", charge.Currency,
+			"reference", reference)
+	}
+	if !instalmentPlanOpen(plan) {
+		s.logger.InfoWithContext(ctx, "Plano de parcelamento encerrado",
+			"tenant_id", plan.TenantID,
+			"plan_id", plan.ID,
+			"transaction_id", plan.TransactionID,
+			"status", plan.Status)
+	}
+	s.metricsRecorder.CounterInc("payment_instalment_captures_total", map[string]string{
+		"market": charge.RegionCode,
+		"status": outcome,
+	})
+	return plan, reference, captureErr
+}
+
+// recordCapture regista o resultado de uma captura reservada e retorna o plano atualizado.
+// A recusa da primeira parcela cancela o plano; as seguintes ficam em atraso até nova tentativa
+func (s *InstalmentService) recordCapture(ctx context.Context, charge InstalmentCharge, reference string, captureErr error) (*InstalmentPlan, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.capturing, instalmentPlanKey(charge.TenantID, charge.PlanID))
+	plan, err := s.store.GetInstalmentPlan(ctx, charge.TenantID, charge.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+
+	switch {
+	case charge.Number == 0 && captureErr != nil:
+		return plan, nil
+
+	case charge.Number == 0:
+		for i := range plan.Instalments {
+			instalment := &plan.Instalments[i]
+			if instalment.Status == InstalmentStatusPaid {
+				continue
+			}
+			instalment.Status = InstalmentStatusSettledEarly
+			instalment.Reference = reference
+			instalment.NextAttemptAt = nil
+			instalment.PaidAt = &now
+		}
+		settlement := *charge.Settlement
+		settlement.Reference = reference
+		settlement.SettledAt = &now
+		plan.EarlySettlement = &settlement
+		plan.Status = InstalmentPlanSettled
+		plan.DelinquentSince = nil
+
+	case captureErr != nil:
+		instalment := &plan.Instalments[charge.Number-1]
+		instalment.Attempts++
+		instalment.LastError = captureErr.Error()
+		if charge.Number == 1 && instalment.Status == InstalmentStatusScheduled {
+			plan.Status = InstalmentPlanCancelled
+			break
+		}
+		nextAttempt := now.Add(s.config.RetryInterval)
+		instalment.Status = InstalmentStatusOverdue
+		instalment.NextAttemptAt = &nextAttempt
+
+	default:
+		instalment := &plan.Instalments[charge.Number-1]
+		instalment.Attempts++
+		instalment.Status = InstalmentStatusPaid
+		instalment.Reference = reference
+		instalment.LastError = ""
+		instalment.NextAttemptAt = nil
+		instalment.PaidAt = &now
+
+		switch {
+		case firstUnpaidInstalment(plan) == nil:
+			plan.Status = InstalmentPlanSettled
+			plan.DelinquentSince = nil
+		case plan.Status == InstalmentPlanDelinquent && !instalmentPlanOverdue(plan):
+			// Parcelas em atraso regularizadas
+			plan.Status = InstalmentPlanActive
+			plan.DelinquentSince = nil
+		}
+	}
+
+	if err := s.store.SaveInstalmentPlan(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// markDelinquent marca em incumprimento os planos com uma parcela em atraso há mais dias do que a
+// tolerância do mercado e retorna os planos marcados nesta passagem
+func (s *InstalmentService) markDelinquent(ctx context.Context) ([]*InstalmentPlan, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	plans, err := s.store.ListInstalmentPlans(ctx, InstalmentPlanFilter{Status: InstalmentPlanActive})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	var delinquent []*InstalmentPlan
+	for _, plan := range plans {
+		grace := time.Duration(s.config.MarketRules[plan.RegionCode].GraceDays) * 24 * time.Hour
+		for _, instalment := range plan.Instalments {
+			if instalment.Status == InstalmentStatusOverdue && now.Sub(instalment.DueDate) > grace {
+				plan.Status = InstalmentPlanDelinquent
+				plan.DelinquentSince = &now
+				if err := s.store.SaveInstalmentPlan(ctx, plan); err != nil {
+					return delinquent, err
+				}
+				delinquent = append(delinquent, plan)
+				break
+			}
+		}
+	}
+	return delinquent, nil
+}
+
+// earlySettlement soma as parcelas vencidas e o capital por vencer, dispensa os juros futuros e aplica
+// a comissão do mercado sobre o capital antecipado
+func (s *InstalmentService) earlySettlement(plan *InstalmentPlan) *InstalmentEarlySettlement {
+	now := s.now()
+	settlement := &InstalmentEarlySettlement{}
+	remaining := 0
+	for _, instalment := range plan.Instalments {
+		if instalment.Status == InstalmentStatusPaid {
+			continue
+		}
+		if !instalment.DueDate.After(now) {
+			settlement.OverdueAmount += instalment.Amount
+			continue
+		}
+		remaining++
+		settlement.Principal += instalment.Principal
+		settlement.InterestWaived += instalment.Interest
+	}
+
+	rule := s.config.MarketRules[plan.RegionCode]
+	feeRate := rule.EarlySettlementFeeRate
+	if remaining <= 12 && rule.EarlySettlementFeeRateFinalYear > 0 {
+		feeRate = rule.EarlySettlementFeeRateFinalYear
+	}
+	settlement.OverdueAmount = roundAmount(settlement.OverdueAmount)
+	settlement.Principal = roundAmount(settlement.Principal)
+	settlement.InterestWaived = roundAmount(settlement.InterestWaived)
+	settlement.Fee = roundAmount(settlement.Principal * feeRate)
+	settlement.Amount = roundAmount(settlement.OverdueAmount + settlement.Principal + settlement.Fee)
+	return settlement
+}
+
+// notify agenda a notificação ao comerciante do resultado da captura de uma parcela
+func (s *InstalmentService) notify(ctx context.Context, plan *InstalmentPlan, charge InstalmentCharge, eventType, reference, reason string) {
+	if s.webhooks == nil {
+		return
+	}
+
+	status := TransactionStatusApproved
+	if eventType == WebhookEventTransactionFailed {
+		status = TransactionStatusDenied
+	}
+	if _, err := s.webhooks.Enqueue(ctx, WebhookEvent{
+		EventType:     eventType,
+		TenantID:      plan.TenantID,
+		MerchantID:    plan.MerchantID,
+		TransactionID: charge.TransactionID,
+		Status:        status,
+		PaymentMethod: plan.FundingMethod,
+		Amount:        charge.Amount,
+		Currency:      charge.Currency,
+		ProcessorRef:  reference,
+		Reason:        reason,
+		Market:        plan.RegionCode,
+	}); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao agendar notificação da parcela",
+			"plan_id", plan.ID,
+			"transaction_id", charge.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// instalmentCharge cria a captura de uma parcela do plano (nil para a liquidação antecipada), com a
+// transação derivada da compra: <transação>-P01, -P02... ou <transação>-LA
+func instalmentCharge(plan *InstalmentPlan, instalment *Instalment) InstalmentCharge {
+	charge := InstalmentCharge{
+		PlanID:        plan.ID,
+		TenantID:      plan.TenantID,
+		TransactionID: plan.TransactionID + "-LA",
+		MerchantID:    plan.MerchantID,
+		UserID:        plan.UserID,
+		RegionCode:    plan.RegionCode,
+		FundingMethod: plan.FundingMethod,
+		CardTokenID:   plan.CardTokenID,
+		Currency:      plan.Currency,
+		Description:   fmt.Sprintf("Liquidação antecipada do plano %s", plan.ID),
+	}
+	if instalment != nil {
+		charge.TransactionID = fmt.Sprintf("%s-P%02d", plan.TransactionID, instalment.Number)
+		charge.Number = instalment.Number
+		charge.Amount = instalment.Amount
+		charge.Description = fmt.Sprintf("Parcela %d/%d do plano %s", instalment.Number, plan.Count, plan.ID)
+	}
+	return charge
+}
+
+// instalmentPlanKey identifica o plano nas reservas de captura
+func instalmentPlanKey(tenantID, planID string) string {
+	return tenantID + "/" + planID
+}
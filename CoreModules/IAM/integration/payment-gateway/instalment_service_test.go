@@ -0,0 +1,538 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// fakeInstalmentCaptureClient simula a API de captura do PSP e regista as capturas recebidas
+type fakeInstalmentCaptureClient struct {
+	mu      sync.Mutex
+	err     error
+	charges []InstalmentCharge
+}
+
+func (f *fakeInstalmentCaptureClient) Capture(ctx context.Context, charge InstalmentCharge) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.charges = append(f.charges, charge)
+	if f.err != nil {
+		return "", f.err
+	}
+	return "CAP-" + charge.TransactionID, nil
+}
+
+func (f *fakeInstalmentCaptureClient) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeInstalmentCaptureClient) captured() []InstalmentCharge {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]InstalmentCharge(nil), f.charges...)
+}
+
+func newTestInstalmentService(t *testing.T, client InstalmentCaptureClient) (*InstalmentService, *InMemoryInstalmentStore, *time.Time) {
+	t.Helper()
+
+	store := NewInMemoryInstalmentStore()
+	service, err := NewInstalmentService(InstalmentConfig{}, store, client)
+	require.NoError(t, err)
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func testInstalmentRequest(region, currency string, amount float64, count int, monthlyRate float64) *PaymentRequest {
+	req := testRiskRequest(region, currency, amount)
+	req.PaymentMethod = PaymentMethodInstalment
+	req.CardTokenID = "tok-1"
+	req.PaymentDetails = map[string]interface{}{"instalments": float64(count)}
+	if monthlyRate > 0 {
+		req.PaymentDetails["monthly_interest_rate"] = monthlyRate
+	}
+	return req
+}
+
+func TestBuildInstalmentSchedule(t *testing.T) {
+	start := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		principal float64
+		rate      float64
+		count     int
+		amounts   []float64
+		interest  []float64
+	}{
+		{
+			name:      "sem juros com arredondamento na última parcela",
+			principal: 1000,
+			count:     3,
+			amounts:   []float64{333.33, 333.33, 333.34},
+			interest:  []float64{0, 0, 0},
+		},
+		{
+			name:      "tabela Price com a primeira parcela sem juros",
+			principal: 1000,
+			rate:      0.02,
+			count:     3,
+			amounts:   []float64{339.96, 339.96, 339.95},
+			interest:  []float64{0, 13.2, 6.67},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instalments := buildInstalmentSchedule(tt.principal, tt.rate, tt.count, start)
+			require.Len(t, instalments, tt.count)
+
+			principal := 0.0
+			for i, instalment := range instalments {
+				assert.Equal(t, i+1, instalment.Number)
+				assert.Equal(t, tt.amounts[i], instalment.Amount)
+				assert.Equal(t, tt.interest[i], instalment.Interest)
+				assert.Equal(t, InstalmentStatusScheduled, instalment.Status)
+				principal += instalment.Principal
+			}
+			assert.Equal(t, tt.principal, roundAmount(principal), "o capital amortizado é o valor da compra")
+		})
+	}
+
+	t.Run("vencimentos limitados ao último dia do mês", func(t *testing.T) {
+		instalments := buildInstalmentSchedule(300, 0, 3, start)
+		assert.Equal(t, start, instalments[0].DueDate)
+		assert.Equal(t, time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC), instalments[1].DueDate)
+		assert.Equal(t, time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC), instalments[2].DueDate)
+	})
+
+	assert.Equal(t, 0.2682, annualEquivalentRate(0.02))
+	assert.Zero(t, annualEquivalentRate(0))
+}
+
+func TestInstalmentCreatePlanRejections(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  func() *PaymentRequest
+		expected error
+	}{
+		{
+			name:     "mercado sem parcelamento",
+			request:  func() *PaymentRequest { return testInstalmentRequest("XX", "USD", 1000, 3, 0) },
+			expected: ErrInstalmentMarketUnsupported,
+		},
+		{
+			name:     "número de parcelas ausente",
+			request:  func() *PaymentRequest { return testInstalmentRequest(RegionBrazil, "BRL", 1000, 0, 0) },
+			expected: ErrInstalmentCountInvalid,
+		},
+		{
+			name:     "acima do máximo do mercado",
+			request:  func() *PaymentRequest { return testInstalmentRequest(RegionAngola, "AOA", 100000, 7, 0.01) },
+			expected: ErrInstalmentCountInvalid,
+		},
+		{
+			name:     "taxa acima do máximo do mercado",
+			request:  func() *PaymentRequest { return testInstalmentRequest(RegionEU, "EUR", 1000, 6, 0.03) },
+			expected: ErrInstalmentRateInvalid,
+		},
+		{
+			name:     "sem juros acima do limite do mercado",
+			request:  func() *PaymentRequest { return testInstalmentRequest(RegionEU, "EUR", 1000, 6, 0) },
+			expected: ErrInstalmentInterestRequired,
+		},
+		{
+			name:     "parcela abaixo do mínimo",
+			request:  func() *PaymentRequest { return testInstalmentRequest(RegionBrazil, "BRL", 40, 12, 0) },
+			expected: ErrInstalmentAmountTooLow,
+		},
+		{
+			name: "parcelas num estorno",
+			request: func() *PaymentRequest {
+				req := testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0)
+				req.PaymentDetails["funding_method"] = PaymentMethodRefund
+				return req
+			},
+			expected: ErrInstalmentFundingInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeInstalmentCaptureClient{}
+			service, store, _ := newTestInstalmentService(t, client)
+
+			_, _, err := service.Execute(context.Background(), tt.request())
+			assert.ErrorIs(t, err, ErrInstalmentRejected)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Empty(t, client.captured())
+
+			plans, err := store.ListInstalmentPlans(context.Background(), InstalmentPlanFilter{})
+			require.NoError(t, err)
+			assert.Empty(t, plans)
+		})
+	}
+}
+
+func TestInstalmentExecuteCapturesFirstInstalment(t *testing.T) {
+	client := &fakeInstalmentCaptureClient{}
+	service, _, clock := newTestInstalmentService(t, client)
+
+	plan, reference, err := service.Execute(context.Background(), testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0.02))
+	require.NoError(t, err)
+	assert.Equal(t, "CAP-tx-1-P01", reference)
+	assert.Equal(t, InstalmentPlanActive, plan.Status)
+	assert.Equal(t, 0.2682, plan.AnnualRate)
+	assert.Equal(t, 19.87, plan.TotalInterest)
+	assert.Equal(t, 1019.87, plan.TotalAmount)
+	assert.Equal(t, PaymentMethodCard, plan.FundingMethod)
+	assert.Equal(t, InstalmentStatusPaid, plan.Instalments[0].Status)
+	assert.Equal(t, *clock, *plan.Instalments[0].PaidAt)
+	assert.Equal(t, InstalmentStatusScheduled, plan.Instalments[1].Status)
+
+	charges := client.captured()
+	require.Len(t, charges, 1)
+	assert.Equal(t, "tx-1-P01", charges[0].TransactionID)
+	assert.Equal(t, "tok-1", charges[0].CardTokenID)
+	assert.Equal(t, 339.96, charges[0].Amount)
+
+	// Parcelas ainda não vencidas não são capturadas
+	service.CaptureDue(context.Background())
+	assert.Len(t, client.captured(), 1)
+}
+
+func TestInstalmentExecuteFirstCaptureDeclinedCancelsPlan(t *testing.T) {
+	client := &fakeInstalmentCaptureClient{err: fmt.Errorf("%w: HTTP 402", ErrInstalmentCaptureDeclined)}
+	service, _, _ := newTestInstalmentService(t, client)
+
+	plan, _, err := service.Execute(context.Background(), testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+	assert.ErrorIs(t, err, ErrInstalmentCaptureDeclined)
+	require.NotNil(t, plan)
+	assert.Equal(t, InstalmentPlanCancelled, plan.Status)
+
+	// Planos cancelados não têm parcelas capturadas
+	client.setErr(nil)
+	service.CaptureDue(context.Background())
+	assert.Len(t, client.captured(), 1)
+}
+
+func TestInstalmentCaptureDueRetriesAndMarksDelinquent(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeInstalmentCaptureClient{}
+	service, _, clock := newTestInstalmentService(t, client)
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	service.SetWebhookDeliveryService(webhooks)
+
+	plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+	require.NoError(t, err)
+
+	// Segunda parcela vencida e recusada: fica em atraso até à nova tentativa
+	client.setErr(fmt.Errorf("%w: HTTP 402", ErrInstalmentCaptureDeclined))
+	*clock = clock.AddDate(0, 1, 0)
+	service.CaptureDue(ctx)
+	stored, err := service.GetPlan(ctx, "tenant-1", plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, InstalmentPlanActive, stored.Status)
+	assert.Equal(t, InstalmentStatusOverdue, stored.Instalments[1].Status)
+	assert.Equal(t, clock.Add(DefaultInstalmentRetryInterval), *stored.Instalments[1].NextAttemptAt)
+
+	service.CaptureDue(ctx)
+	assert.Len(t, client.captured(), 2, "sem nova tentativa antes do intervalo")
+
+	summary, err := service.Delinquency(ctx, "tenant-1", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, &InstalmentDelinquency{UserID: "user-1", OverdueInstalments: 1, OverdueAmount: 333.33}, summary)
+
+	// Atraso acima da tolerância de 15 dias do Brasil: o plano entra em incumprimento
+	*clock = clock.AddDate(0, 0, 16)
+	service.CaptureDue(ctx)
+	stored, err = service.GetPlan(ctx, "tenant-1", plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, InstalmentPlanDelinquent, stored.Status)
+	assert.Equal(t, 2, stored.Instalments[1].Attempts)
+
+	summary, err = service.Delinquency(ctx, "tenant-1", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.DelinquentPlans)
+
+	// Parcela regularizada: o plano volta a ativo
+	client.setErr(nil)
+	*clock = clock.Add(DefaultInstalmentRetryInterval)
+	service.CaptureDue(ctx)
+	stored, err = service.GetPlan(ctx, "tenant-1", plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, InstalmentPlanActive, stored.Status)
+	assert.Nil(t, stored.DelinquentSince)
+	assert.Equal(t, InstalmentStatusPaid, stored.Instalments[1].Status)
+	assert.Equal(t, "CAP-tx-1-P02", stored.Instalments[1].Reference)
+
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	events := []string{list[0].Event.EventType, list[1].Event.EventType, list[2].Event.EventType}
+	assert.ElementsMatch(t, []string{WebhookEventTransactionFailed, WebhookEventTransactionFailed,
+		WebhookEventTransactionCompleted}, events)
+	assert.Equal(t, "tx-1-P02", list[0].Event.TransactionID)
+	assert.Equal(t, PaymentMethodCard, list[0].Event.PaymentMethod)
+}
+
+func TestInstalmentEarlySettlement(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("comissão de 1% com mais de 12 parcelas por vencer na UE", func(t *testing.T) {
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionEU, "EUR", 2400, 24, 0.01))
+		require.NoError(t, err)
+
+		quote, err := service.QuoteEarlySettlement(ctx, "tenant-1", plan.ID)
+		require.NoError(t, err)
+		assert.Zero(t, quote.OverdueAmount)
+		assert.Equal(t, roundAmount(plan.Principal-plan.Instalments[0].Principal), quote.Principal)
+		assert.Equal(t, plan.TotalInterest, quote.InterestWaived)
+		assert.Equal(t, roundAmount(quote.Principal*0.01), quote.Fee)
+		assert.Equal(t, roundAmount(quote.Principal+quote.Fee), quote.Amount)
+	})
+
+	t.Run("comissão de 0,5% no último ano e liquidação", func(t *testing.T) {
+		client := &fakeInstalmentCaptureClient{}
+		service, _, _ := newTestInstalmentService(t, client)
+		plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionEU, "EUR", 1200, 12, 0.01))
+		require.NoError(t, err)
+
+		quote, err := service.QuoteEarlySettlement(ctx, "tenant-1", plan.ID)
+		require.NoError(t, err)
+		assert.Equal(t, roundAmount(quote.Principal*0.005), quote.Fee)
+
+		settled, err := service.SettleEarly(ctx, "tenant-1", plan.ID)
+		require.NoError(t, err)
+		assert.Equal(t, InstalmentPlanSettled, settled.Status)
+		assert.Equal(t, quote.Amount, settled.EarlySettlement.Amount)
+		assert.Equal(t, "CAP-tx-1-LA", settled.EarlySettlement.Reference)
+		assert.Equal(t, InstalmentStatusPaid, settled.Instalments[0].Status)
+		for _, instalment := range settled.Instalments[1:] {
+			assert.Equal(t, InstalmentStatusSettledEarly, instalment.Status)
+		}
+
+		charges := client.captured()
+		require.Len(t, charges, 2)
+		assert.Equal(t, "tx-1-LA", charges[1].TransactionID)
+		assert.Equal(t, quote.Amount, charges[1].Amount)
+
+		_, err = service.SettleEarly(ctx, "tenant-1", plan.ID)
+		assert.ErrorIs(t, err, ErrInstalmentPlanClosed)
+	})
+
+	t.Run("no Brasil a liquidação apenas dispensa os juros", func(t *testing.T) {
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0.02))
+		require.NoError(t, err)
+
+		quote, err := service.QuoteEarlySettlement(ctx, "tenant-1", plan.ID)
+		require.NoError(t, err)
+		assert.Zero(t, quote.Fee)
+		assert.Equal(t, 19.87, quote.InterestWaived)
+		assert.Equal(t, 660.04, quote.Amount)
+	})
+
+	t.Run("recusa da captura mantém o plano", func(t *testing.T) {
+		client := &fakeInstalmentCaptureClient{}
+		service, _, _ := newTestInstalmentService(t, client)
+		plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+		require.NoError(t, err)
+
+		client.setErr(fmt.Errorf("%w: HTTP 402", ErrInstalmentCaptureDeclined))
+		_, err = service.SettleEarly(ctx, "tenant-1", plan.ID)
+		assert.ErrorIs(t, err, ErrInstalmentCaptureDeclined)
+
+		stored, err := service.GetPlan(ctx, "tenant-1", plan.ID)
+		require.NoError(t, err)
+		assert.Equal(t, InstalmentPlanActive, stored.Status)
+		assert.Nil(t, stored.EarlySettlement)
+	})
+
+	t.Run("plano de outro tenant", func(t *testing.T) {
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		plan, _, err := service.Execute(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+		require.NoError(t, err)
+
+		_, err = service.QuoteEarlySettlement(ctx, "tenant-2", plan.ID)
+		assert.ErrorIs(t, err, ErrInstalmentPlanNotFound)
+	})
+}
+
+func TestHTTPInstalmentCaptureClient(t *testing.T) {
+	var idempotencyKey string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey = r.Header.Get(resilience.IdempotencyKeyHeader)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["amount"].(float64) > 500 {
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"capture_reference": "PSP-123"})
+	}))
+	defer server.Close()
+
+	client := NewHTTPInstalmentCaptureClient(InstalmentConfig{
+		CaptureEndpoint: server.URL,
+		Resilience:      resilience.Policy{MaxAttempts: 1},
+	})
+
+	reference, err := client.Capture(context.Background(), InstalmentCharge{TransactionID: "tx-1-P02", Amount: 100})
+	require.NoError(t, err)
+	assert.Equal(t, "PSP-123", reference)
+	assert.Equal(t, "tx-1-P02", idempotencyKey)
+	assert.Equal(t, true, body["merchant_initiated"])
+
+	_, err = client.Capture(context.Background(), InstalmentCharge{TransactionID: "tx-1-P03", Amount: 1000})
+	assert.ErrorIs(t, err, ErrInstalmentCaptureDeclined)
+}
+
+func TestInstalmentHandler(t *testing.T) {
+	service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+	plan, _, err := service.Execute(context.Background(), testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	NewInstalmentHandler(service).RegisterRoutes(router)
+	serve := func(method, path, tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Tenant-ID", tenantID)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("lista os planos do usuário", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/support/instalments?user_id=user-1", "tenant-1")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var plans []InstalmentPlan
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&plans))
+		require.Len(t, plans, 1)
+		assert.Equal(t, plan.ID, plans[0].ID)
+	})
+
+	t.Run("incumprimento do usuário", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/support/instalments/delinquency/user-1", "tenant-1")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var summary InstalmentDelinquency
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&summary))
+		assert.Zero(t, summary.DelinquentPlans)
+	})
+
+	t.Run("plano de outro tenant", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/support/instalments/"+plan.ID, "tenant-2")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("simulação e liquidação antecipada", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/support/instalments/"+plan.ID+"/settlement", "tenant-1")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(http.MethodPost, "/support/instalments/"+plan.ID+"/settlement", "tenant-1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var settled InstalmentPlan
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&settled))
+		assert.Equal(t, InstalmentPlanSettled, settled.Status)
+
+		rec = serve(http.MethodPost, "/support/instalments/"+plan.ID+"/settlement", "tenant-1")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+}
+
+func TestProcessPaymentInstalment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("compra parcelada aprovada cria o plano", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		connector.SetInstalmentService(service)
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0.02))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.Equal(t, "CAP-tx-1-P01", response.AuthorizationID)
+		assert.Equal(t, 3, response.Metadata["instalment_count"])
+		assert.Equal(t, 339.96, response.Metadata["instalment_amount"])
+		assert.Equal(t, 0.2682, response.Metadata["instalment_annual_rate"])
+	})
+
+	t.Run("condições fora das regras do mercado", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		connector.SetInstalmentService(service)
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 13, 0))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "parcelamento_recusado", response.StatusCode)
+	})
+
+	t.Run("primeira parcela recusada", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{
+			err: fmt.Errorf("%w: HTTP 402", ErrInstalmentCaptureDeclined),
+		})
+		connector.SetInstalmentService(service)
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "parcela_recusada", response.StatusCode)
+	})
+
+	t.Run("PSP indisponível devolve erro", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _, _ := newTestInstalmentService(t, &fakeInstalmentCaptureClient{err: errors.New("PSP indisponível")})
+		connector.SetInstalmentService(service)
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusError, response.Status)
+		assert.Equal(t, "parcelamento_erro", response.StatusCode)
+	})
+
+	t.Run("usuário em incumprimento não tem novas compras parceladas", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		connector.SetRiskEngine(newTestRiskEngine(t, NewInMemoryTransactionRecordStore()))
+		service, store, clock := newTestInstalmentService(t, &fakeInstalmentCaptureClient{})
+		connector.SetInstalmentService(service)
+
+		delinquentSince := clock.Add(-24 * time.Hour)
+		require.NoError(t, store.SaveInstalmentPlan(ctx, &InstalmentPlan{
+			ID:              "plan-0",
+			TenantID:        "tenant-1",
+			TransactionID:   "tx-0",
+			UserID:          "user-1",
+			RegionCode:      RegionBrazil,
+			Status:          InstalmentPlanDelinquent,
+			Instalments:     []Instalment{{Number: 2, Amount: 100, Status: InstalmentStatusOverdue}},
+			CreatedAt:       clock.AddDate(0, -2, 0),
+			DelinquentSince: &delinquentSince,
+		}))
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "risco_rejeitado", response.StatusCode)
+	})
+}
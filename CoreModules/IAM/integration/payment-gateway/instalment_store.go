@@ -0,0 +1,87 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// InstalmentStore define a persistência dos planos de parcelamento
+type InstalmentStore interface {
+	// SaveInstalmentPlan grava o plano, substituindo o estado anterior
+	SaveInstalmentPlan(ctx context.Context, plan *InstalmentPlan) error
+
+	// GetInstalmentPlan recupera o plano do tenant; retorna ErrInstalmentPlanNotFound quando não existe
+	GetInstalmentPlan(ctx context.Context, tenantID, planID string) (*InstalmentPlan, error)
+
+	// ListInstalmentPlans lista os planos do filtro por ordem de criação
+	ListInstalmentPlans(ctx context.Context, filter InstalmentPlanFilter) ([]*InstalmentPlan, error)
+}
+
+// InMemoryInstalmentStore armazena os planos de parcelamento em memória
+type InMemoryInstalmentStore struct {
+	plans map[string]*InstalmentPlan
+	mutex sync.RWMutex
+}
+
+// NewInMemoryInstalmentStore cria um novo armazenamento em memória
+func NewInMemoryInstalmentStore() *InMemoryInstalmentStore {
+	return &InMemoryInstalmentStore{plans: make(map[string]*InstalmentPlan)}
+}
+
+// SaveInstalmentPlan grava uma cópia do plano
+func (s *InMemoryInstalmentStore) SaveInstalmentPlan(ctx context.Context, plan *InstalmentPlan) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.plans[plan.TenantID+"/"+plan.ID] = copyInstalmentPlan(plan)
+	return nil
+}
+
+// GetInstalmentPlan retorna uma cópia do plano do tenant
+func (s *InMemoryInstalmentStore) GetInstalmentPlan(ctx context.Context, tenantID, planID string) (*InstalmentPlan, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	plan, ok := s.plans[tenantID+"/"+planID]
+	if !ok {
+		return nil, ErrInstalmentPlanNotFound
+	}
+	return copyInstalmentPlan(plan), nil
+}
+
+// ListInstalmentPlans retorna cópias dos planos do filtro por ordem de criação
+func (s *InMemoryInstalmentStore) ListInstalmentPlans(ctx context.Context, filter InstalmentPlanFilter) ([]*InstalmentPlan, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	plans := make([]*InstalmentPlan, 0)
+	for _, plan := range s.plans {
+		if filter.TenantID != "" && plan.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.UserID != "" && plan.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != "" && plan.Status != filter.Status {
+			continue
+		}
+		if filter.OpenOnly && !instalmentPlanOpen(plan) {
+			continue
+		}
+		plans = append(plans, copyInstalmentPlan(plan))
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt.Before(plans[j].CreatedAt) })
+	return plans, nil
+}
+
+// copyInstalmentPlan copia o plano, as parcelas e a liquidação antecipada
+func copyInstalmentPlan(plan *InstalmentPlan) *InstalmentPlan {
+	copied := *plan
+	copied.Instalments = append([]Instalment(nil), plan.Instalments...)
+	if plan.EarlySettlement != nil {
+		settlement := *plan.EarlySettlement
+		copied.EarlySettlement = &settlement
+	}
+	return &copied
+}
@@ -0,0 +1,58 @@
+package paymentgateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// computeHash calcula o hash SHA-256 do registo e do hash anterior
+// Cada campo é prefixado pelo seu tamanho para que a concatenação não seja ambígua
+func (e *ManualPayoutAuditEntry) computeHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.TenantID,
+		strconv.FormatInt(e.Sequence, 10),
+		e.PayoutID,
+		e.Action,
+		e.Actor,
+		e.Role,
+		e.Detail,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	var builder strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&builder, "%d:%s", len(field), field)
+	}
+	sum := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// nextManualPayoutAuditEntry liga um novo registo ao último registo da cadeia do tenant
+func nextManualPayoutAuditEntry(last *ManualPayoutAuditEntry, entry ManualPayoutAuditEntry) *ManualPayoutAuditEntry {
+	entry.Sequence = 1
+	entry.PrevHash = manualPayoutAuditGenesisHash
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+	}
+	entry.Hash = entry.computeHash()
+	return &entry
+}
+
+// VerifyManualPayoutAuditChain verifica a cadeia de um tenant desde o primeiro registo
+// Retorna a sequência do primeiro registo inválido, ou zero quando a cadeia está íntegra
+func VerifyManualPayoutAuditChain(entries []*ManualPayoutAuditEntry) int64 {
+	expectedPrev := manualPayoutAuditGenesisHash
+	for i, entry := range entries {
+		if entry.Sequence != int64(i+1) || entry.PrevHash != expectedPrev || entry.Hash != entry.computeHash() {
+			return int64(i + 1)
+		}
+		expectedPrev = entry.Hash
+	}
+	return 0
+}
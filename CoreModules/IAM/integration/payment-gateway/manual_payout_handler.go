@@ -0,0 +1,215 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ManualPayoutHandler expõe às equipas de operações os pagamentos manuais com controlo duplo
+// O operador e as suas funções vêm da autenticação da API de suporte; X-Support-Role apenas escolhe
+// uma das funções autenticadas com que o operador atua
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type ManualPayoutHandler struct {
+	service *ManualPayoutService
+}
+
+// NewManualPayoutHandler cria uma nova instância do ManualPayoutHandler
+func NewManualPayoutHandler(service *ManualPayoutService) *ManualPayoutHandler {
+	return &ManualPayoutHandler{service: service}
+}
+
+// manualPayoutDecisionRequest é o corpo da rejeição de um pagamento manual
+type manualPayoutDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *ManualPayoutHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/payouts", h.List).Methods(http.MethodGet)
+	router.HandleFunc("/support/payouts", h.Initiate).Methods(http.MethodPost)
+	router.HandleFunc("/support/payouts/sla", h.SLA).Methods(http.MethodGet)
+	router.HandleFunc("/support/payouts/audit", h.VerifyAudit).Methods(http.MethodGet)
+	router.HandleFunc("/support/payouts/{payoutId}", h.Get).Methods(http.MethodGet)
+	router.HandleFunc("/support/payouts/{payoutId}/audit", h.AuditTrail).Methods(http.MethodGet)
+	router.HandleFunc("/support/payouts/{payoutId}/approve", h.Approve).Methods(http.MethodPost)
+	router.HandleFunc("/support/payouts/{payoutId}/reject", h.Reject).Methods(http.MethodPost)
+}
+
+// List lista os pagamentos manuais do tenant filtrados por status
+func (h *ManualPayoutHandler) List(w http.ResponseWriter, r *http.Request) {
+	payouts, err := h.service.List(r.Context(), ManualPayoutFilter{
+		TenantID: r.Header.Get("X-Tenant-ID"),
+		Status:   r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar pagamentos manuais")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payouts)
+}
+
+// Initiate inicia um pagamento manual: 202 quando aguarda aprovação, 201 quando executado
+func (h *ManualPayoutHandler) Initiate(w http.ResponseWriter, r *http.Request) {
+	operator, role, err := manualPayoutActor(r)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao iniciar pagamento manual")
+		return
+	}
+
+	var req ManualPayoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+	req.Operator = operator
+	req.Role = role
+
+	payout, err := h.service.Initiate(r.Context(), req)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao iniciar pagamento manual")
+		return
+	}
+
+	status := http.StatusCreated
+	if payout.Status == ManualPayoutPendingApproval {
+		status = http.StatusAccepted
+	}
+	respondWithJSON(w, status, payout)
+}
+
+// SLA resume os pagamentos do tenant a aguardar aprovação
+func (h *ManualPayoutHandler) SLA(w http.ResponseWriter, r *http.Request) {
+	sla, err := h.service.SLA(r.Context(), r.Header.Get("X-Tenant-ID"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao calcular SLA de aprovação")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sla)
+}
+
+// VerifyAudit verifica a cadeia de auditoria dos pagamentos manuais do tenant
+func (h *ManualPayoutHandler) VerifyAudit(w http.ResponseWriter, r *http.Request) {
+	verification, err := h.service.VerifyAudit(r.Context(), r.Header.Get("X-Tenant-ID"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao verificar cadeia de auditoria")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, verification)
+}
+
+// Get retorna um pagamento manual do tenant
+func (h *ManualPayoutHandler) Get(w http.ResponseWriter, r *http.Request) {
+	payout, err := h.service.Get(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["payoutId"])
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao recuperar pagamento manual")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payout)
+}
+
+// AuditTrail lista os registos de auditoria de um pagamento manual
+func (h *ManualPayoutHandler) AuditTrail(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.AuditTrail(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["payoutId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar registos de auditoria")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+// Approve aprova um pagamento acima do limiar e executa-o
+func (h *ManualPayoutHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	operator, role, err := manualPayoutActor(r)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao aprovar pagamento manual")
+		return
+	}
+
+	payout, err := h.service.Approve(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["payoutId"], operator, role)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao aprovar pagamento manual")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payout)
+}
+
+// Reject rejeita um pagamento acima do limiar com o motivo indicado
+func (h *ManualPayoutHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	operator, role, err := manualPayoutActor(r)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao rejeitar pagamento manual")
+		return
+	}
+
+	var req manualPayoutDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	payout, err := h.service.Reject(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["payoutId"], operator, role, req.Reason)
+	if err != nil {
+		h.respondWithPayoutError(w, err, "Erro ao rejeitar pagamento manual")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payout)
+}
+
+// manualPayoutActor retorna o operador autenticado e a função com que atua: a indicada em
+// X-Support-Role, se lhe estiver atribuída, ou a sua única função
+func manualPayoutActor(r *http.Request) (string, string, error) {
+	principal, ok := SupportPrincipalFrom(r.Context())
+	if !ok || principal.Operator == "" {
+		return "", "", ErrManualPayoutOperatorMissing
+	}
+
+	if requested := strings.TrimSpace(r.Header.Get("X-Support-Role")); requested != "" {
+		if !principal.HasRole(requested) {
+			return "", "", ErrManualPayoutRoleNotHeld
+		}
+		return principal.Operator, requested, nil
+	}
+	switch len(principal.Roles) {
+	case 0:
+		return "", "", ErrManualPayoutOperatorMissing
+	case 1:
+		return principal.Operator, principal.Roles[0], nil
+	default:
+		return "", "", ErrManualPayoutRoleAmbiguous
+	}
+}
+
+// respondWithPayoutError traduz os erros do serviço de pagamentos manuais em respostas HTTP
+func (h *ManualPayoutHandler) respondWithPayoutError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrManualPayoutOperatorMissing):
+		respondWithError(w, http.StatusUnauthorized, "operator_required", err.Error())
+	case errors.Is(err, ErrManualPayoutRoleAmbiguous):
+		respondWithError(w, http.StatusBadRequest, "role_ambiguous", err.Error())
+	case errors.Is(err, ErrManualPayoutSameOperator), errors.Is(err, ErrManualPayoutSameRole),
+		errors.Is(err, ErrManualPayoutRoleNotAllowed), errors.Is(err, ErrManualPayoutRoleNotHeld):
+		respondWithError(w, http.StatusForbidden, "dual_control_denied", err.Error())
+	case errors.Is(err, ErrManualPayoutInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrManualPayoutNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Pagamento manual não encontrado")
+	case errors.Is(err, ErrManualPayoutNotPending):
+		respondWithError(w, http.StatusConflict, "not_pending", "Pagamento manual não está a aguardar aprovação")
+	case errors.Is(err, ErrManualPayoutFailed):
+		respondWithError(w, http.StatusBadGateway, "payout_failed", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
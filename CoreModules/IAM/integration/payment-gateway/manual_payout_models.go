@@ -0,0 +1,178 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estados de um pagamento manual
+const (
+	ManualPayoutPendingApproval = "pending_approval" // Acima do limiar do mercado: aguarda um segundo operador
+	ManualPayoutApproved        = "approved"         // Aprovado; crédito em curso no parceiro
+	ManualPayoutExecuted        = "executed"
+	ManualPayoutRejected        = "rejected"
+	ManualPayoutFailed          = "failed" // Crédito recusado pelo parceiro
+)
+
+// Ações registadas na cadeia de auditoria dos pagamentos manuais
+const (
+	ManualPayoutActionInitiated = "initiated"
+	ManualPayoutActionApproved  = "approved"
+	ManualPayoutActionRejected  = "rejected"
+	ManualPayoutActionExecuted  = "executed"
+	ManualPayoutActionFailed    = "failed"
+)
+
+// Valores padrão do controlo duplo dos pagamentos manuais
+const (
+	DefaultManualPayoutApprovalSLA = 4 * time.Hour // Tempo máximo em aprovação antes de alertar
+	DefaultManualPayoutSLAInterval = time.Minute   // Periodicidade da verificação do SLA de aprovação
+
+	// manualPayoutAuditGenesisHash é o hash anterior do primeiro registo da cadeia de cada tenant
+	manualPayoutAuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	// manualPayoutAuditAttempts limita as novas tentativas quando outra réplica acrescenta à cadeia em simultâneo
+	manualPayoutAuditAttempts = 3
+
+	// manualPayoutSystemActor é o ator dos registos do desfecho do crédito no parceiro
+	manualPayoutSystemActor = "system"
+)
+
+// DefaultManualPayoutApproverRoles são as funções que podem aprovar pagamentos manuais acima do limiar
+var DefaultManualPayoutApproverRoles = []string{"payments_supervisor", "treasury_manager"}
+
+// Erros dos pagamentos manuais
+var (
+	ErrManualPayoutInvalid         = errors.New("pedido de pagamento manual inválido")
+	ErrManualPayoutNotFound        = errors.New("pagamento manual não encontrado")
+	ErrManualPayoutNotPending      = errors.New("pagamento manual não está a aguardar aprovação")
+	ErrManualPayoutOperatorMissing = errors.New("operador e função obrigatórios")
+	ErrManualPayoutSameOperator    = errors.New("o aprovador deve ser um operador diferente do iniciador")
+	ErrManualPayoutSameRole        = errors.New("o aprovador deve ter uma função diferente do iniciador")
+	ErrManualPayoutRoleNotAllowed  = errors.New("função sem permissão para aprovar pagamentos manuais")
+	ErrManualPayoutRoleNotHeld     = errors.New("função não atribuída ao operador autenticado")
+	ErrManualPayoutRoleAmbiguous   = errors.New("operador com várias funções: indique a função em X-Support-Role")
+	ErrManualPayoutMarketUnknown   = errors.New("mercado sem regras de pagamentos manuais")
+	ErrManualPayoutFailed          = errors.New("falha no crédito do pagamento manual")
+	ErrManualPayoutAuditConflict   = errors.New("sequência da cadeia de auditoria já registada")
+)
+
+// ManualPayoutMarketRule define o limiar de controlo duplo dos pagamentos manuais num mercado
+type ManualPayoutMarketRule struct {
+	Market               string  `json:"market"`
+	Currency             string  `json:"currency"`               // Moeda dos pagamentos manuais no mercado
+	DualControlThreshold float64 `json:"dual_control_threshold"` // Valores acima exigem a aprovação de um segundo operador
+}
+
+// DefaultManualPayoutMarketRules retorna os limiares de controlo duplo por mercado
+func DefaultManualPayoutMarketRules() map[string]ManualPayoutMarketRule {
+	rules := []ManualPayoutMarketRule{
+		{Market: RegionAngola, Currency: "AOA", DualControlThreshold: 5000000},
+		{Market: RegionBrazil, Currency: "BRL", DualControlThreshold: 50000},
+		{Market: RegionEU, Currency: "EUR", DualControlThreshold: 10000},
+		{Market: RegionMozambique, Currency: "MZN", DualControlThreshold: 500000},
+		{Market: RegionUSA, Currency: "USD", DualControlThreshold: 10000},
+		{Market: RegionGlobal, Currency: "USD", DualControlThreshold: 10000},
+	}
+
+	byMarket := make(map[string]ManualPayoutMarketRule, len(rules))
+	for _, rule := range rules {
+		byMarket[rule.Market] = rule
+	}
+	return byMarket
+}
+
+// ManualPayoutConfig contém configurações dos pagamentos manuais das equipas de operações
+type ManualPayoutConfig struct {
+	Rules         map[string]ManualPayoutMarketRule `json:"rules"`          // Padrão: DefaultManualPayoutMarketRules
+	ApproverRoles []string                          `json:"approver_roles"` // Padrão: DefaultManualPayoutApproverRoles
+	ApprovalSLA   time.Duration                     `json:"approval_sla"`   // Tempo máximo em aprovação antes de alertar
+	SLAInterval   time.Duration                     `json:"sla_interval"`   // Periodicidade da verificação do SLA
+
+	// Parceiros de pagamento por identificador; os créditos usam os mesmos parceiros das remessas
+	Partners      map[string]RemittancePartnerConfig `json:"partners"`
+	PayoutTimeout time.Duration                      `json:"payout_timeout"`
+	Resilience    resilience.Policy                  `json:"resilience"`
+}
+
+// ManualPayoutRequest é o pedido de pagamento manual de um operador
+type ManualPayoutRequest struct {
+	TenantID    string                `json:"-"`
+	Market      string                `json:"market"`
+	Partner     string                `json:"partner"`
+	Amount      float64               `json:"amount"`
+	Currency    string                `json:"currency"`
+	Beneficiary RemittanceBeneficiary `json:"beneficiary"`
+	PurposeCode string                `json:"purpose_code,omitempty"`
+	Reason      string                `json:"reason"`
+	Operator    string                `json:"-"` // Operador autenticado da API de suporte
+	Role        string                `json:"-"` // Função com que o operador atua
+}
+
+// ManualPayout é um pagamento manual e as decisões dos operadores
+type ManualPayout struct {
+	PayoutID         string                `json:"payout_id" db:"payout_id"`
+	TenantID         string                `json:"tenant_id" db:"tenant_id"`
+	Market           string                `json:"market" db:"market"`
+	Partner          string                `json:"partner" db:"partner"`
+	Amount           float64               `json:"amount" db:"amount"`
+	Currency         string                `json:"currency" db:"currency"`
+	Beneficiary      RemittanceBeneficiary `json:"beneficiary" db:"-"`
+	PurposeCode      string                `json:"purpose_code,omitempty" db:"purpose_code"`
+	Reason           string                `json:"reason" db:"reason"`
+	Status           string                `json:"status" db:"status"`
+	DualControl      bool                  `json:"dual_control" db:"dual_control"` // Valor acima do limiar do mercado
+	Threshold        float64               `json:"threshold" db:"threshold"`
+	InitiatedBy      string                `json:"initiated_by" db:"initiated_by"`
+	InitiatorRole    string                `json:"initiator_role" db:"initiator_role"`
+	DecidedBy        string                `json:"decided_by,omitempty" db:"decided_by"`
+	DeciderRole      string                `json:"decider_role,omitempty" db:"decider_role"`
+	RejectionReason  string                `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	PartnerReference string                `json:"partner_reference,omitempty" db:"partner_reference"`
+	Error            string                `json:"error,omitempty" db:"error"`
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+	ApprovalDueAt    *time.Time            `json:"approval_due_at,omitempty" db:"approval_due_at"` // Fim do SLA de aprovação
+	SLABreached      bool                  `json:"sla_breached" db:"sla_breached"`                 // Alerta de SLA já emitido
+	DecidedAt        *time.Time            `json:"decided_at,omitempty" db:"decided_at"`
+	ExecutedAt       *time.Time            `json:"executed_at,omitempty" db:"executed_at"`
+}
+
+// ManualPayoutFilter seleciona os pagamentos manuais listados
+type ManualPayoutFilter struct {
+	TenantID string
+	Status   string
+}
+
+// ManualPayoutAuditEntry é um registo da cadeia de auditoria dos pagamentos manuais do tenant, ligado
+// ao anterior pelo hash para que a alteração ou remoção de um registo seja detetável
+type ManualPayoutAuditEntry struct {
+	TenantID   string    `json:"tenant_id" db:"tenant_id"`
+	Sequence   int64     `json:"sequence" db:"sequence"`
+	PayoutID   string    `json:"payout_id" db:"payout_id"`
+	Action     string    `json:"action" db:"action"`
+	Actor      string    `json:"actor" db:"actor"`
+	Role       string    `json:"role,omitempty" db:"role"`
+	Detail     string    `json:"detail,omitempty" db:"detail"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+	PrevHash   string    `json:"prev_hash" db:"prev_hash"`
+	Hash       string    `json:"hash" db:"hash"`
+}
+
+// ManualPayoutAuditVerification é o resultado da verificação da cadeia de auditoria do tenant
+type ManualPayoutAuditVerification struct {
+	Entries          int       `json:"entries"`
+	Valid            bool      `json:"valid"`
+	BrokenAtSequence int64     `json:"broken_at_sequence,omitempty"` // Primeiro registo inválido
+	HeadHash         string    `json:"head_hash,omitempty"`
+	VerifiedAt       time.Time `json:"verified_at"`
+}
+
+// ManualPayoutSLA resume os pagamentos manuais a aguardar aprovação
+type ManualPayoutSLA struct {
+	Pending              int     `json:"pending"`
+	Breached             int     `json:"breached"` // Pendentes além do SLA de aprovação
+	OldestPendingSeconds float64 `json:"oldest_pending_seconds"`
+	ApprovalSLASeconds   float64 `json:"approval_sla_seconds"`
+}
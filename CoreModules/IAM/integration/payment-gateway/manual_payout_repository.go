@@ -0,0 +1,202 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresManualPayoutStore implementa ManualPayoutStore para PostgreSQL
+type PostgresManualPayoutStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresManualPayoutStore cria uma nova instância de PostgresManualPayoutStore
+func NewPostgresManualPayoutStore(db *sqlx.DB) *PostgresManualPayoutStore {
+	return &PostgresManualPayoutStore{db: db}
+}
+
+// dbManualPayout é a representação de ManualPayout na base de dados
+type dbManualPayout struct {
+	ManualPayout
+	BeneficiaryJSON []byte `db:"beneficiary"`
+	FromStatus      string `db:"from_status"`
+}
+
+// manualPayoutColumns são as colunas lidas nas consultas de pagamentos manuais
+const manualPayoutColumns = `payout_id, tenant_id, market, partner, amount, currency, beneficiary, purpose_code,
+	reason, status, dual_control, threshold, initiated_by, initiator_role, decided_by, decider_role,
+	rejection_reason, partner_reference, error, created_at, approval_due_at, sla_breached, decided_at, executed_at`
+
+// manualPayoutAuditColumns são as colunas lidas nas consultas da cadeia de auditoria
+const manualPayoutAuditColumns = `tenant_id, sequence, payout_id, action, actor, role, detail, occurred_at, prev_hash, hash`
+
+// newDBManualPayout codifica o beneficiário do pagamento em JSONB
+func newDBManualPayout(payout *ManualPayout) (*dbManualPayout, error) {
+	row := &dbManualPayout{ManualPayout: *payout}
+	var err error
+	if row.BeneficiaryJSON, err = json.Marshal(payout.Beneficiary); err != nil {
+		return nil, fmt.Errorf("falha ao codificar beneficiário: %w", err)
+	}
+	return row, nil
+}
+
+// CreateManualPayout grava um novo pagamento manual
+func (r *PostgresManualPayoutStore) CreateManualPayout(ctx context.Context, payout *ManualPayout) error {
+	row, err := newDBManualPayout(payout)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.manual_payouts (
+			payout_id, tenant_id, market, partner, amount, currency, beneficiary, purpose_code,
+			reason, status, dual_control, threshold, initiated_by, initiator_role, decided_by, decider_role,
+			rejection_reason, partner_reference, error, created_at, approval_due_at, sla_breached, decided_at, executed_at
+		) VALUES (
+			:payout_id, :tenant_id, :market, :partner, :amount, :currency, :beneficiary, :purpose_code,
+			:reason, :status, :dual_control, :threshold, :initiated_by, :initiator_role, :decided_by, :decider_role,
+			:rejection_reason, :partner_reference, :error, :created_at, :approval_due_at, :sla_breached, :decided_at, :executed_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar pagamento manual: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateManualPayout grava o pagamento se o estado atual for fromStatus
+func (r *PostgresManualPayoutStore) UpdateManualPayout(ctx context.Context, payout *ManualPayout, fromStatus string) error {
+	row, err := newDBManualPayout(payout)
+	if err != nil {
+		return err
+	}
+	row.FromStatus = fromStatus
+
+	query := `
+		UPDATE payment_gateway.manual_payouts SET
+			status = :status,
+			decided_by = :decided_by,
+			decider_role = :decider_role,
+			rejection_reason = :rejection_reason,
+			partner_reference = :partner_reference,
+			error = :error,
+			sla_breached = :sla_breached,
+			decided_at = :decided_at,
+			executed_at = :executed_at
+		WHERE tenant_id = :tenant_id AND payout_id = :payout_id AND status = :from_status
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, row)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar pagamento manual: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+	if affected == 0 {
+		return ErrManualPayoutNotPending
+	}
+
+	return nil
+}
+
+// GetManualPayout recupera o pagamento do tenant
+func (r *PostgresManualPayoutStore) GetManualPayout(ctx context.Context, tenantID, payoutID string) (*ManualPayout, error) {
+	var row dbManualPayout
+	query := `SELECT ` + manualPayoutColumns + ` FROM payment_gateway.manual_payouts
+		WHERE tenant_id = $1 AND payout_id = $2`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, payoutID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrManualPayoutNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar pagamento manual: %w", err)
+	}
+	return row.decode()
+}
+
+// ListManualPayouts lista os pagamentos do filtro por ordem de criação
+func (r *PostgresManualPayoutStore) ListManualPayouts(ctx context.Context, filter ManualPayoutFilter) ([]*ManualPayout, error) {
+	var rows []dbManualPayout
+	query := `SELECT ` + manualPayoutColumns + ` FROM payment_gateway.manual_payouts
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &rows, query, filter.TenantID, filter.Status); err != nil {
+		return nil, fmt.Errorf("falha ao listar pagamentos manuais: %w", err)
+	}
+
+	payouts := make([]*ManualPayout, 0, len(rows))
+	for i := range rows {
+		payout, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, payout)
+	}
+	return payouts, nil
+}
+
+// decode descodifica o beneficiário gravado em JSONB
+func (row *dbManualPayout) decode() (*ManualPayout, error) {
+	payout := row.ManualPayout
+	if err := json.Unmarshal(row.BeneficiaryJSON, &payout.Beneficiary); err != nil {
+		return nil, fmt.Errorf("falha ao descodificar beneficiário: %w", err)
+	}
+	return &payout, nil
+}
+
+// AppendManualPayoutAudit acrescenta o registo à cadeia do tenant; a chave (tenant_id, sequence)
+// impede que duas réplicas liguem registos ao mesmo antecessor
+func (r *PostgresManualPayoutStore) AppendManualPayoutAudit(ctx context.Context, entry *ManualPayoutAuditEntry) error {
+	query := `
+		INSERT INTO payment_gateway.manual_payout_audit (
+			tenant_id, sequence, payout_id, action, actor, role, detail, occurred_at, prev_hash, hash
+		) VALUES (
+			:tenant_id, :sequence, :payout_id, :action, :actor, :role, :detail, :occurred_at, :prev_hash, :hash
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, entry); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrManualPayoutAuditConflict
+		}
+		return fmt.Errorf("falha ao gravar registo de auditoria do pagamento manual: %w", err)
+	}
+
+	return nil
+}
+
+// LastManualPayoutAudit retorna o último registo da cadeia do tenant
+func (r *PostgresManualPayoutStore) LastManualPayoutAudit(ctx context.Context, tenantID string) (*ManualPayoutAuditEntry, error) {
+	var entry ManualPayoutAuditEntry
+	query := `SELECT ` + manualPayoutAuditColumns + ` FROM payment_gateway.manual_payout_audit
+		WHERE tenant_id = $1 ORDER BY sequence DESC LIMIT 1`
+	if err := r.db.GetContext(ctx, &entry, query, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar último registo de auditoria: %w", err)
+	}
+	return &entry, nil
+}
+
+// ListManualPayoutAudit lista os registos por sequência
+func (r *PostgresManualPayoutStore) ListManualPayoutAudit(ctx context.Context, tenantID, payoutID string) ([]*ManualPayoutAuditEntry, error) {
+	entries := make([]*ManualPayoutAuditEntry, 0)
+	query := `SELECT ` + manualPayoutAuditColumns + ` FROM payment_gateway.manual_payout_audit
+		WHERE tenant_id = $1 AND ($2 = '' OR payout_id = $2)
+		ORDER BY sequence`
+	if err := r.db.SelectContext(ctx, &entries, query, tenantID, payoutID); err != nil {
+		return nil, fmt.Errorf("falha ao listar registos de auditoria dos pagamentos manuais: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,529 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// ManualPayoutService aplica o controlo duplo (maker-checker) aos pagamentos manuais das equipas de
+// operações: abaixo do limiar do mercado o pagamento é executado de imediato; acima fica pendente até
+// um segundo operador, com outra função, o aprovar ou rejeitar. Cada ação é registada na cadeia de
+// auditoria do tenant
+type ManualPayoutService struct {
+	config        ManualPayoutConfig
+	store         ManualPayoutStore
+	client        RemittancePayoutClient
+	approverRoles map[string]bool
+
+	// Serializa os acréscimos à cadeia de auditoria nesta réplica; entre réplicas, a sequência
+	// única na cadeia do tenant rejeita o segundo acréscimo, que é repetido sobre o novo último registo
+	auditMutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewManualPayoutService cria o serviço de pagamentos manuais. Sem cliente é usada a API HTTP dos
+// parceiros de pagamento das remessas
+func NewManualPayoutService(config ManualPayoutConfig, store ManualPayoutStore, client RemittancePayoutClient) (*ManualPayoutService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-manual-payouts",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if len(config.Rules) == 0 {
+		config.Rules = DefaultManualPayoutMarketRules()
+	}
+	if len(config.ApproverRoles) == 0 {
+		config.ApproverRoles = DefaultManualPayoutApproverRoles
+	}
+	if config.ApprovalSLA <= 0 {
+		config.ApprovalSLA = DefaultManualPayoutApprovalSLA
+	}
+	if config.SLAInterval <= 0 {
+		config.SLAInterval = DefaultManualPayoutSLAInterval
+	}
+	if client == nil {
+		client = NewHTTPRemittancePayoutClient(RemittanceConfig{
+			PayoutTimeout: config.PayoutTimeout,
+			Resilience:    config.Resilience,
+		})
+	}
+
+	approverRoles := make(map[string]bool, len(config.ApproverRoles))
+	for _, role := range config.ApproverRoles {
+		if role = strings.TrimSpace(role); role != "" {
+			approverRoles[role] = true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ManualPayoutService{
+		config:          config,
+		store:           store,
+		client:          client,
+		approverRoles:   approverRoles,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start inicia a verificação periódica do SLA dos pagamentos a aguardar aprovação
+func (s *ManualPayoutService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.SLAInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.CheckSLA(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Verificação do SLA dos pagamentos manuais iniciada", "interval", s.config.SLAInterval.String())
+}
+
+// Stop interrompe a verificação periódica
+func (s *ManualPayoutService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Initiate regista o pagamento do operador iniciador. Acima do limiar do mercado o pagamento fica
+// a aguardar aprovação; abaixo é executado de imediato. As recusas do pedido envolvem ErrManualPayoutInvalid
+// e a recusa do parceiro envolve ErrManualPayoutFailed, com o pagamento falhado
+func (s *ManualPayoutService) Initiate(ctx context.Context, req ManualPayoutRequest) (*ManualPayout, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "ManualPayoutService.Initiate")
+	defer span.End()
+
+	if req.Operator == "" || req.Role == "" {
+		return nil, ErrManualPayoutOperatorMissing
+	}
+	rule, err := s.validate(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("%w: %w", ErrManualPayoutInvalid, err)
+	}
+
+	now := s.now().UTC()
+	payout := &ManualPayout{
+		PayoutID:      uuid.New().String(),
+		TenantID:      req.TenantID,
+		Market:        rule.Market,
+		Partner:       req.Partner,
+		Amount:        roundAmount(req.Amount),
+		Currency:      req.Currency,
+		Beneficiary:   req.Beneficiary,
+		PurposeCode:   req.PurposeCode,
+		Reason:        req.Reason,
+		Status:        ManualPayoutApproved,
+		DualControl:   req.Amount > rule.DualControlThreshold,
+		Threshold:     rule.DualControlThreshold,
+		InitiatedBy:   req.Operator,
+		InitiatorRole: req.Role,
+		CreatedAt:     now,
+	}
+	if payout.DualControl {
+		dueAt := now.Add(s.config.ApprovalSLA)
+		payout.Status = ManualPayoutPendingApproval
+		payout.ApprovalDueAt = &dueAt
+	}
+
+	if err := s.store.CreateManualPayout(ctx, payout); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, payout, ManualPayoutActionInitiated, req.Operator, req.Role,
+		fmt.Sprintf("%.2f %s para %s via %s (controlo duplo: %t): %s", payout.Amount, payout.Currency,
+			payout.Beneficiary.AccountID, payout.Partner, payout.DualControl, payout.Reason)); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Registar a iniciação para auditoria das ações das equipas de operações
+	s.logger.InfoWithContext(ctx, "Pagamento manual iniciado",
+		"tenant_id", payout.TenantID,
+		"payout_id", payout.PayoutID,
+		"market", payout.Market,
+		"amount", payout.Amount,
+		"currency", payout.Currency,
+		"dual_control", payout.DualControl,
+		"operator", payout.InitiatedBy,
+		"role", payout.InitiatorRole)
+
+	if payout.DualControl {
+		s.recordStatus(payout)
+		return payout, nil
+	}
+	return s.execute(ctx, payout)
+}
+
+// validate verifica o pedido e retorna a regra do mercado
+func (s *ManualPayoutService) validate(req ManualPayoutRequest) (ManualPayoutMarketRule, error) {
+	rule, exists := s.config.Rules[req.Market]
+	switch {
+	case req.TenantID == "":
+		return rule, errors.New("tenant obrigatório")
+	case !exists:
+		return rule, fmt.Errorf("%w: %s", ErrManualPayoutMarketUnknown, req.Market)
+	case req.Amount <= 0:
+		return rule, errors.New("valor do pagamento deve ser positivo")
+	case req.Currency != rule.Currency:
+		return rule, fmt.Errorf("moeda %s inválida no mercado %s (esperada %s)", req.Currency, rule.Market, rule.Currency)
+	case req.Beneficiary.Name == "" || req.Beneficiary.AccountID == "":
+		return rule, errors.New("beneficiário do pagamento incompleto")
+	case strings.TrimSpace(req.Reason) == "":
+		return rule, errors.New("motivo do pagamento obrigatório")
+	}
+	if _, exists := s.config.Partners[req.Partner]; !exists {
+		return rule, fmt.Errorf("parceiro de pagamento %s não configurado", req.Partner)
+	}
+	return rule, nil
+}
+
+// Approve regista a aprovação do segundo operador e executa o pagamento. O aprovador tem de ser
+// outro operador, com uma função diferente do iniciador e autorizada a aprovar
+func (s *ManualPayoutService) Approve(ctx context.Context, tenantID, payoutID, operator, role string) (*ManualPayout, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "ManualPayoutService.Approve")
+	defer span.End()
+
+	payout, err := s.decide(ctx, tenantID, payoutID, operator, role, ManualPayoutApproved, "")
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, payout, ManualPayoutActionApproved, operator, role,
+		fmt.Sprintf("aprovado após %s", payout.DecidedAt.Sub(payout.CreatedAt).Round(time.Second))); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.HistogramObserve("payment_gateway_manual_payout_approval_seconds",
+		payout.DecidedAt.Sub(payout.CreatedAt).Seconds(), map[string]string{
+			"market":   payout.Market,
+			"decision": ManualPayoutApproved,
+		})
+	return s.execute(ctx, payout)
+}
+
+// Reject regista a rejeição do segundo operador, com as mesmas exigências da aprovação
+func (s *ManualPayoutService) Reject(ctx context.Context, tenantID, payoutID, operator, role, reason string) (*ManualPayout, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "ManualPayoutService.Reject")
+	defer span.End()
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: motivo da rejeição obrigatório", ErrManualPayoutInvalid)
+	}
+
+	payout, err := s.decide(ctx, tenantID, payoutID, operator, role, ManualPayoutRejected, reason)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, payout, ManualPayoutActionRejected, operator, role, reason); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.HistogramObserve("payment_gateway_manual_payout_approval_seconds",
+		payout.DecidedAt.Sub(payout.CreatedAt).Seconds(), map[string]string{
+			"market":   payout.Market,
+			"decision": ManualPayoutRejected,
+		})
+	s.recordStatus(payout)
+	return payout, nil
+}
+
+// decide valida o segundo operador e grava a decisão se o pagamento ainda estiver pendente
+func (s *ManualPayoutService) decide(ctx context.Context, tenantID, payoutID, operator, role, status, reason string) (*ManualPayout, error) {
+	if operator == "" || role == "" {
+		return nil, ErrManualPayoutOperatorMissing
+	}
+	payout, err := s.store.GetManualPayout(ctx, tenantID, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	if payout.Status != ManualPayoutPendingApproval {
+		return nil, ErrManualPayoutNotPending
+	}
+
+	var denied error
+	switch {
+	case operator == payout.InitiatedBy:
+		denied = ErrManualPayoutSameOperator
+	case role == payout.InitiatorRole:
+		denied = ErrManualPayoutSameRole
+	case !s.approverRoles[role]:
+		denied = ErrManualPayoutRoleNotAllowed
+	}
+	if denied != nil {
+		s.metricsRecorder.CounterInc("payment_gateway_manual_payout_decisions_denied", map[string]string{
+			"market": payout.Market,
+			"reason": manualPayoutDenialReason(denied),
+		})
+		// Evento de segurança: tentativa de contornar o controlo duplo
+		s.logger.WarnWithContext(ctx, "Decisão de pagamento manual recusada pelo controlo duplo",
+			"tenant_id", tenantID,
+			"payout_id", payoutID,
+			"operator", operator,
+			"role", role,
+			"initiated_by", payout.InitiatedBy,
+			"error", denied.Error())
+		return nil, denied
+	}
+
+	now := s.now().UTC()
+	payout.Status = status
+	payout.RejectionReason = reason
+	payout.DecidedBy = operator
+	payout.DeciderRole = role
+	payout.DecidedAt = &now
+	if err := s.store.UpdateManualPayout(ctx, payout, ManualPayoutPendingApproval); err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// manualPayoutDenialReason retorna o rótulo de métrica da recusa do controlo duplo
+func manualPayoutDenialReason(err error) string {
+	switch {
+	case errors.Is(err, ErrManualPayoutSameOperator):
+		return "same_operator"
+	case errors.Is(err, ErrManualPayoutSameRole):
+		return "same_role"
+	default:
+		return "role_not_allowed"
+	}
+}
+
+// execute envia o crédito ao parceiro e regista o desfecho
+func (s *ManualPayoutService) execute(ctx context.Context, payout *ManualPayout) (*ManualPayout, error) {
+	reference, payoutErr := s.client.Payout(ctx, s.config.Partners[payout.Partner], PayoutInstruction{
+		TransactionID: payout.PayoutID,
+		Amount:        payout.Amount,
+		Currency:      payout.Currency,
+		Beneficiary:   payout.Beneficiary,
+		PurposeCode:   payout.PurposeCode,
+	})
+
+	action, detail := ManualPayoutActionExecuted, "referência do parceiro "+reference
+	if payoutErr != nil {
+		payout.Status = ManualPayoutFailed
+		payout.Error = payoutErr.Error()
+		action, detail = ManualPayoutActionFailed, payoutErr.Error()
+	} else {
+		now := s.now().UTC()
+		payout.Status = ManualPayoutExecuted
+		payout.PartnerReference = reference
+		payout.ExecutedAt = &now
+	}
+
+	if err := s.store.UpdateManualPayout(ctx, payout, ManualPayoutApproved); err != nil {
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, payout, action, manualPayoutSystemActor, "", detail); err != nil {
+		return nil, err
+	}
+	s.recordStatus(payout)
+
+	if payoutErr != nil {
+		s.logger.ErrorWithContext(ctx, "Falha no crédito do pagamento manual",
+			"tenant_id", payout.TenantID,
+			"payout_id", payout.PayoutID,
+			"partner", payout.Partner,
+			"error", payoutErr.Error())
+		return payout, fmt.Errorf("%w: %w", ErrManualPayoutFailed, payoutErr)
+	}
+
+	s.logger.InfoWithContext(ctx, "Pagamento manual executado",
+		"tenant_id", payout.TenantID,
+		"payout_id", payout.PayoutID,
+		"partner", payout.Partner,
+		"partner_reference", payout.PartnerReference)
+	return payout, nil
+}
+
+// appendAudit acrescenta um registo à cadeia de auditoria do tenant, repetindo sobre o novo último
+// registo quando outra réplica acrescenta em simultâneo
+func (s *ManualPayoutService) appendAudit(ctx context.Context, payout *ManualPayout, action, actor, role, detail string) error {
+	s.auditMutex.Lock()
+	defer s.auditMutex.Unlock()
+
+	var err error
+	for attempt := 0; attempt < manualPayoutAuditAttempts; attempt++ {
+		var last *ManualPayoutAuditEntry
+		if last, err = s.store.LastManualPayoutAudit(ctx, payout.TenantID); err != nil {
+			break
+		}
+		entry := nextManualPayoutAuditEntry(last, ManualPayoutAuditEntry{
+			TenantID:   payout.TenantID,
+			PayoutID:   payout.PayoutID,
+			Action:     action,
+			Actor:      actor,
+			Role:       role,
+			Detail:     detail,
+			OccurredAt: s.now().UTC(),
+		})
+		if err = s.store.AppendManualPayoutAudit(ctx, entry); !errors.Is(err, ErrManualPayoutAuditConflict) {
+			break
+		}
+	}
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar ação do pagamento manual na cadeia de auditoria",
+			"tenant_id", payout.TenantID,
+			"payout_id", payout.PayoutID,
+			"action", action,
+			"error", err.Error())
+		return fmt.Errorf("falha ao auditar pagamento manual: %w", err)
+	}
+	return nil
+}
+
+// recordStatus contabiliza o estado do pagamento manual
+func (s *ManualPayoutService) recordStatus(payout *ManualPayout) {
+	s.metricsRecorder.CounterInc("payment_gateway_manual_payouts", map[string]string{
+		"market": payout.Market,
+		"status": payout.Status,
+	})
+}
+
+// Get retorna o pagamento manual do tenant
+func (s *ManualPayoutService) Get(ctx context.Context, tenantID, payoutID string) (*ManualPayout, error) {
+	return s.store.GetManualPayout(ctx, tenantID, payoutID)
+}
+
+// List retorna os pagamentos manuais do filtro, mais antigos primeiro
+func (s *ManualPayoutService) List(ctx context.Context, filter ManualPayoutFilter) ([]*ManualPayout, error) {
+	return s.store.ListManualPayouts(ctx, filter)
+}
+
+// AuditTrail retorna os registos da cadeia de um pagamento, ou de toda a cadeia do tenant quando
+// payoutID é vazio
+func (s *ManualPayoutService) AuditTrail(ctx context.Context, tenantID, payoutID string) ([]*ManualPayoutAuditEntry, error) {
+	return s.store.ListManualPayoutAudit(ctx, tenantID, payoutID)
+}
+
+// VerifyAudit verifica a integridade da cadeia de auditoria do tenant
+func (s *ManualPayoutService) VerifyAudit(ctx context.Context, tenantID string) (*ManualPayoutAuditVerification, error) {
+	entries, err := s.store.ListManualPayoutAudit(ctx, tenantID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &ManualPayoutAuditVerification{
+		Entries:          len(entries),
+		BrokenAtSequence: VerifyManualPayoutAuditChain(entries),
+		VerifiedAt:       s.now().UTC(),
+	}
+	verification.Valid = verification.BrokenAtSequence == 0
+	if len(entries) > 0 {
+		verification.HeadHash = entries[len(entries)-1].Hash
+	}
+	if !verification.Valid {
+		// Evento de segurança: a cadeia de auditoria foi alterada
+		s.logger.ErrorWithContext(ctx, "Cadeia de auditoria dos pagamentos manuais inválida",
+			"tenant_id", tenantID,
+			"broken_at_sequence", verification.BrokenAtSequence)
+	}
+	return verification, nil
+}
+
+// SLA resume os pagamentos do tenant a aguardar aprovação; vazio resume todos os tenants
+func (s *ManualPayoutService) SLA(ctx context.Context, tenantID string) (*ManualPayoutSLA, error) {
+	pending, err := s.store.ListManualPayouts(ctx, ManualPayoutFilter{TenantID: tenantID, Status: ManualPayoutPendingApproval})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	sla := &ManualPayoutSLA{Pending: len(pending), ApprovalSLASeconds: s.config.ApprovalSLA.Seconds()}
+	for _, payout := range pending {
+		if age := now.Sub(payout.CreatedAt).Seconds(); age > sla.OldestPendingSeconds {
+			sla.OldestPendingSeconds = age
+		}
+		if payout.ApprovalDueAt != nil && now.After(*payout.ApprovalDueAt) {
+			sla.Breached++
+		}
+	}
+	return sla, nil
+}
+
+// CheckSLA publica as métricas dos pagamentos a aguardar aprovação e alerta uma única vez sobre
+// cada pagamento que ultrapassou o SLA de aprovação. Retorna os pagamentos alertados
+func (s *ManualPayoutService) CheckSLA(ctx context.Context) []*ManualPayout {
+	pending, err := s.store.ListManualPayouts(ctx, ManualPayoutFilter{Status: ManualPayoutPendingApproval})
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao verificar SLA dos pagamentos manuais", "error", err.Error())
+		return nil
+	}
+
+	now := s.now().UTC()
+	var oldest float64
+	breached := 0
+	alerted := make([]*ManualPayout, 0)
+	for _, payout := range pending {
+		if age := now.Sub(payout.CreatedAt).Seconds(); age > oldest {
+			oldest = age
+		}
+		if payout.ApprovalDueAt == nil || !now.After(*payout.ApprovalDueAt) {
+			continue
+		}
+		breached++
+		if payout.SLABreached {
+			continue
+		}
+
+		// Marcar o pagamento para que o alerta não se repita; outra réplica ou uma decisão
+		// entretanto gravada fazem a atualização falhar e o alerta é omitido
+		payout.SLABreached = true
+		if err := s.store.UpdateManualPayout(ctx, payout, ManualPayoutPendingApproval); err != nil {
+			continue
+		}
+		s.logger.WarnWithContext(ctx, "Pagamento manual além do SLA de aprovação",
+			"tenant_id", payout.TenantID,
+			"payout_id", payout.PayoutID,
+			"amount", payout.Amount,
+			"currency", payout.Currency,
+			"initiated_by", payout.InitiatedBy,
+			"approval_due_at", payout.ApprovalDueAt.Format(time.RFC3339))
+		s.metricsRecorder.CounterInc("payment_gateway_manual_payout_sla_breaches", map[string]string{
+			"market": payout.Market,
+		})
+		alerted = append(alerted, payout)
+	}
+
+	s.metricsRecorder.GaugeSet("payment_gateway_manual_payouts_pending", float64(len(pending)), map[string]string{"state": "total"})
+	s.metricsRecorder.GaugeSet("payment_gateway_manual_payouts_pending", float64(breached), map[string]string{"state": "sla_breached"})
+	s.metricsRecorder.GaugeSet("payment_gateway_manual_payouts_pending_oldest_seconds", oldest, nil)
+	return alerted
+}
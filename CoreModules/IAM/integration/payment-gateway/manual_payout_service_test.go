@@ -0,0 +1,313 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManualPayoutService(t *testing.T, client RemittancePayoutClient) (*ManualPayoutService, *InMemoryManualPayoutStore, *time.Time) {
+	t.Helper()
+
+	store := NewInMemoryManualPayoutStore()
+	service, err := NewManualPayoutService(ManualPayoutConfig{
+		Partners: map[string]RemittancePartnerConfig{
+			"emis": {PartnerID: "emis", Endpoint: "https://emis.example/payouts"},
+		},
+	}, store, client)
+	require.NoError(t, err)
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func testManualPayoutRequest(amount float64) ManualPayoutRequest {
+	return ManualPayoutRequest{
+		TenantID:    "tenant-1",
+		Market:      RegionAngola,
+		Partner:     "emis",
+		Amount:      amount,
+		Currency:    "AOA",
+		Beneficiary: RemittanceBeneficiary{Name: "Maria Silva", Country: "AO", AccountID: "AO06004000010123456789012"},
+		Reason:      "Reembolso de cobrança em duplicado",
+		Operator:    "ana",
+		Role:        "payments_operator",
+	}
+}
+
+func TestManualPayoutBelowThresholdExecutesImmediately(t *testing.T) {
+	client := &fakePayoutClient{}
+	service, _, _ := newTestManualPayoutService(t, client)
+
+	payout, err := service.Initiate(context.Background(), testManualPayoutRequest(100000))
+	require.NoError(t, err)
+
+	assert.False(t, payout.DualControl)
+	assert.Equal(t, ManualPayoutExecuted, payout.Status)
+	assert.Equal(t, "PAY-"+payout.PayoutID, payout.PartnerReference)
+	assert.Equal(t, 1, client.calls())
+
+	entries, err := service.AuditTrail(context.Background(), "tenant-1", payout.PayoutID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ManualPayoutActionInitiated, entries[0].Action)
+	assert.Equal(t, ManualPayoutActionExecuted, entries[1].Action)
+}
+
+func TestManualPayoutValidation(t *testing.T) {
+	service, _, _ := newTestManualPayoutService(t, &fakePayoutClient{})
+
+	tests := []struct {
+		name   string
+		modify func(req *ManualPayoutRequest)
+		want   error
+	}{
+		{"sem operador", func(req *ManualPayoutRequest) { req.Operator = "" }, ErrManualPayoutOperatorMissing},
+		{"sem função", func(req *ManualPayoutRequest) { req.Role = "" }, ErrManualPayoutOperatorMissing},
+		{"mercado desconhecido", func(req *ManualPayoutRequest) { req.Market = "XX" }, ErrManualPayoutMarketUnknown},
+		{"valor não positivo", func(req *ManualPayoutRequest) { req.Amount = 0 }, ErrManualPayoutInvalid},
+		{"moeda do mercado", func(req *ManualPayoutRequest) { req.Currency = "USD" }, ErrManualPayoutInvalid},
+		{"parceiro desconhecido", func(req *ManualPayoutRequest) { req.Partner = "other" }, ErrManualPayoutInvalid},
+		{"beneficiário incompleto", func(req *ManualPayoutRequest) { req.Beneficiary.AccountID = "" }, ErrManualPayoutInvalid},
+		{"sem motivo", func(req *ManualPayoutRequest) { req.Reason = " " }, ErrManualPayoutInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testManualPayoutRequest(100)
+			tt.modify(&req)
+			_, err := service.Initiate(context.Background(), req)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestManualPayoutDualControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		role     string
+		want     error
+	}{
+		{"mesmo operador", "ana", "payments_supervisor", ErrManualPayoutSameOperator},
+		{"mesma função", "bruno", "payments_operator", ErrManualPayoutSameRole},
+		{"função sem permissão", "bruno", "support_agent", ErrManualPayoutRoleNotAllowed},
+		{"sem função", "bruno", "", ErrManualPayoutOperatorMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakePayoutClient{}
+			service, _, _ := newTestManualPayoutService(t, client)
+			payout, err := service.Initiate(context.Background(), testManualPayoutRequest(6000000))
+			require.NoError(t, err)
+			require.Equal(t, ManualPayoutPendingApproval, payout.Status)
+
+			_, err = service.Approve(context.Background(), "tenant-1", payout.PayoutID, tt.operator, tt.role)
+			assert.ErrorIs(t, err, tt.want)
+
+			stored, err := service.Get(context.Background(), "tenant-1", payout.PayoutID)
+			require.NoError(t, err)
+			assert.Equal(t, ManualPayoutPendingApproval, stored.Status)
+			assert.Zero(t, client.calls())
+		})
+	}
+}
+
+func TestManualPayoutApproveExecutesOnce(t *testing.T) {
+	client := &fakePayoutClient{}
+	service, _, clock := newTestManualPayoutService(t, client)
+
+	payout, err := service.Initiate(context.Background(), testManualPayoutRequest(6000000))
+	require.NoError(t, err)
+	assert.True(t, payout.DualControl)
+	assert.Equal(t, 5000000.0, payout.Threshold)
+	assert.Zero(t, client.calls())
+
+	*clock = clock.Add(30 * time.Minute)
+	approved, err := service.Approve(context.Background(), "tenant-1", payout.PayoutID, "bruno", "payments_supervisor")
+	require.NoError(t, err)
+	assert.Equal(t, ManualPayoutExecuted, approved.Status)
+	assert.Equal(t, "bruno", approved.DecidedBy)
+	assert.Equal(t, "payments_supervisor", approved.DeciderRole)
+	assert.Equal(t, 1, client.calls())
+
+	_, err = service.Approve(context.Background(), "tenant-1", payout.PayoutID, "carla", "treasury_manager")
+	assert.ErrorIs(t, err, ErrManualPayoutNotPending)
+	assert.Equal(t, 1, client.calls())
+
+	_, err = service.Approve(context.Background(), "tenant-2", payout.PayoutID, "bruno", "payments_supervisor")
+	assert.ErrorIs(t, err, ErrManualPayoutNotFound)
+
+	entries, err := service.AuditTrail(context.Background(), "tenant-1", payout.PayoutID)
+	require.NoError(t, err)
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action+"/"+entry.Actor)
+	}
+	assert.Equal(t, []string{"initiated/ana", "approved/bruno", "executed/system"}, actions)
+}
+
+func TestManualPayoutRejectAndPartnerFailure(t *testing.T) {
+	client := &fakePayoutClient{}
+	service, _, _ := newTestManualPayoutService(t, client)
+
+	payout, err := service.Initiate(context.Background(), testManualPayoutRequest(6000000))
+	require.NoError(t, err)
+
+	_, err = service.Reject(context.Background(), "tenant-1", payout.PayoutID, "bruno", "payments_supervisor", "")
+	assert.ErrorIs(t, err, ErrManualPayoutInvalid)
+
+	rejected, err := service.Reject(context.Background(), "tenant-1", payout.PayoutID, "bruno", "payments_supervisor", "Beneficiário não verificado")
+	require.NoError(t, err)
+	assert.Equal(t, ManualPayoutRejected, rejected.Status)
+	assert.Equal(t, "Beneficiário não verificado", rejected.RejectionReason)
+	assert.Zero(t, client.calls())
+
+	client.err = errors.New("conta encerrada")
+	failed, err := service.Initiate(context.Background(), testManualPayoutRequest(1000))
+	assert.ErrorIs(t, err, ErrManualPayoutFailed)
+	require.NotNil(t, failed)
+	assert.Equal(t, ManualPayoutFailed, failed.Status)
+	assert.Equal(t, "conta encerrada", failed.Error)
+}
+
+func TestManualPayoutAuditChainDetectsTampering(t *testing.T) {
+	service, store, _ := newTestManualPayoutService(t, &fakePayoutClient{})
+
+	payout, err := service.Initiate(context.Background(), testManualPayoutRequest(6000000))
+	require.NoError(t, err)
+	_, err = service.Approve(context.Background(), "tenant-1", payout.PayoutID, "bruno", "payments_supervisor")
+	require.NoError(t, err)
+	_, err = service.Initiate(context.Background(), testManualPayoutRequest(100))
+	require.NoError(t, err)
+
+	verification, err := service.VerifyAudit(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 5, verification.Entries)
+
+	// A cadeia de outro tenant começa na sua própria origem
+	other, err := service.VerifyAudit(context.Background(), "tenant-2")
+	require.NoError(t, err)
+	assert.True(t, other.Valid)
+	assert.Zero(t, other.Entries)
+
+	store.audit["tenant-1"][1].Actor = "carla"
+	verification, err = service.VerifyAudit(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, int64(2), verification.BrokenAtSequence)
+
+	store.audit["tenant-1"][1].Actor = "bruno"
+	store.audit["tenant-1"] = append(store.audit["tenant-1"][:2], store.audit["tenant-1"][3:]...)
+	verification, err = service.VerifyAudit(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, int64(3), verification.BrokenAtSequence)
+}
+
+func TestManualPayoutSLABreachAlertsOnce(t *testing.T) {
+	service, _, clock := newTestManualPayoutService(t, &fakePayoutClient{})
+
+	first, err := service.Initiate(context.Background(), testManualPayoutRequest(6000000))
+	require.NoError(t, err)
+	*clock = clock.Add(3 * time.Hour)
+	_, err = service.Initiate(context.Background(), testManualPayoutRequest(7000000))
+	require.NoError(t, err)
+
+	assert.Empty(t, service.CheckSLA(context.Background()))
+
+	*clock = clock.Add(90 * time.Minute)
+	sla, err := service.SLA(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, sla.Pending)
+	assert.Equal(t, 1, sla.Breached)
+	assert.Equal(t, (4*time.Hour + 30*time.Minute).Seconds(), sla.OldestPendingSeconds)
+	assert.Equal(t, DefaultManualPayoutApprovalSLA.Seconds(), sla.ApprovalSLASeconds)
+
+	alerted := service.CheckSLA(context.Background())
+	require.Len(t, alerted, 1)
+	assert.Equal(t, first.PayoutID, alerted[0].PayoutID)
+	assert.Empty(t, service.CheckSLA(context.Background()))
+
+	// O alerta não impede a decisão posterior
+	_, err = service.Approve(context.Background(), "tenant-1", first.PayoutID, "bruno", "treasury_manager")
+	require.NoError(t, err)
+	sla, err = service.SLA(context.Background(), "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, sla.Pending)
+	assert.Zero(t, sla.Breached)
+}
+
+func TestManualPayoutHandlerActor(t *testing.T) {
+	service, _, _ := newTestManualPayoutService(t, &fakePayoutClient{})
+	router := mux.NewRouter()
+	NewManualPayoutHandler(service).RegisterRoutes(router)
+
+	send := func(method, path, body string, principal *SupportPrincipal, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		if role != "" {
+			req.Header.Set("X-Support-Role", role)
+		}
+		if principal != nil {
+			req = req.WithContext(WithSupportPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body, err := json.Marshal(testManualPayoutRequest(6000000))
+	require.NoError(t, err)
+	maker := &SupportPrincipal{Operator: "ana", Roles: []string{"payments_operator"}}
+	checker := &SupportPrincipal{Operator: "bruno", Roles: []string{"payments_operator", "payments_supervisor"}}
+
+	rec := send(http.MethodPost, "/support/payouts", string(body), nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = send(http.MethodPost, "/support/payouts", string(body), maker, "payments_supervisor")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "função não atribuída ao operador")
+
+	rec = send(http.MethodPost, "/support/payouts", string(body), maker, "")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var payout ManualPayout
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&payout))
+	assert.Equal(t, "ana", payout.InitiatedBy)
+	assert.Equal(t, "payments_operator", payout.InitiatorRole)
+
+	approve := "/support/payouts/" + payout.PayoutID + "/approve"
+	rec = send(http.MethodPost, approve, "", checker, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "várias funções sem X-Support-Role")
+
+	rec = send(http.MethodPost, approve, "", checker, "payments_operator")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "mesma função do iniciador")
+	var errResp errorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errResp))
+	assert.Equal(t, "dual_control_denied", errResp.Code)
+
+	rec = send(http.MethodPost, approve, "", checker, "payments_supervisor")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&payout))
+	assert.Equal(t, ManualPayoutExecuted, payout.Status)
+
+	rec = send(http.MethodPost, approve, "", checker, "payments_supervisor")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = send(http.MethodGet, "/support/payouts/audit", "", checker, "")
+	require.Equal(t, http.StatusOK, rec.Code, "leituras não exigem a escolha da função")
+	var verification ManualPayoutAuditVerification
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&verification))
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 3, verification.Entries)
+}
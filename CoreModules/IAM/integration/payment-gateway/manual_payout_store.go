@@ -0,0 +1,153 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// ManualPayoutStore define a persistência dos pagamentos manuais e da sua cadeia de auditoria
+type ManualPayoutStore interface {
+	// CreateManualPayout grava um novo pagamento manual
+	CreateManualPayout(ctx context.Context, payout *ManualPayout) error
+
+	// UpdateManualPayout grava o pagamento se o estado atual for fromStatus; retorna
+	// ErrManualPayoutNotPending quando outro operador já mudou o estado
+	UpdateManualPayout(ctx context.Context, payout *ManualPayout, fromStatus string) error
+
+	// GetManualPayout recupera o pagamento do tenant; retorna ErrManualPayoutNotFound quando não existe
+	GetManualPayout(ctx context.Context, tenantID, payoutID string) (*ManualPayout, error)
+
+	// ListManualPayouts lista os pagamentos do filtro por ordem de criação
+	ListManualPayouts(ctx context.Context, filter ManualPayoutFilter) ([]*ManualPayout, error)
+
+	// AppendManualPayoutAudit acrescenta o registo à cadeia do tenant; retorna
+	// ErrManualPayoutAuditConflict quando a sequência já foi registada
+	AppendManualPayoutAudit(ctx context.Context, entry *ManualPayoutAuditEntry) error
+
+	// LastManualPayoutAudit retorna o último registo da cadeia do tenant, ou nil quando está vazia
+	LastManualPayoutAudit(ctx context.Context, tenantID string) (*ManualPayoutAuditEntry, error)
+
+	// ListManualPayoutAudit lista por sequência os registos do pagamento, ou de toda a cadeia do
+	// tenant quando payoutID é vazio
+	ListManualPayoutAudit(ctx context.Context, tenantID, payoutID string) ([]*ManualPayoutAuditEntry, error)
+}
+
+// InMemoryManualPayoutStore armazena os pagamentos manuais em memória
+type InMemoryManualPayoutStore struct {
+	payouts map[string]*ManualPayout
+	audit   map[string][]*ManualPayoutAuditEntry
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryManualPayoutStore cria um novo armazenamento em memória
+func NewInMemoryManualPayoutStore() *InMemoryManualPayoutStore {
+	return &InMemoryManualPayoutStore{
+		payouts: make(map[string]*ManualPayout),
+		audit:   make(map[string][]*ManualPayoutAuditEntry),
+	}
+}
+
+// CreateManualPayout grava uma cópia do pagamento
+func (s *InMemoryManualPayoutStore) CreateManualPayout(ctx context.Context, payout *ManualPayout) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *payout
+	s.payouts[payout.TenantID+"/"+payout.PayoutID] = &copied
+	return nil
+}
+
+// UpdateManualPayout grava uma cópia do pagamento se o estado atual for fromStatus
+func (s *InMemoryManualPayoutStore) UpdateManualPayout(ctx context.Context, payout *ManualPayout, fromStatus string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := payout.TenantID + "/" + payout.PayoutID
+	current, ok := s.payouts[key]
+	if !ok {
+		return ErrManualPayoutNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrManualPayoutNotPending
+	}
+	copied := *payout
+	s.payouts[key] = &copied
+	return nil
+}
+
+// GetManualPayout retorna uma cópia do pagamento do tenant
+func (s *InMemoryManualPayoutStore) GetManualPayout(ctx context.Context, tenantID, payoutID string) (*ManualPayout, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	payout, ok := s.payouts[tenantID+"/"+payoutID]
+	if !ok {
+		return nil, ErrManualPayoutNotFound
+	}
+	copied := *payout
+	return &copied, nil
+}
+
+// ListManualPayouts retorna cópias dos pagamentos do filtro por ordem de criação
+func (s *InMemoryManualPayoutStore) ListManualPayouts(ctx context.Context, filter ManualPayoutFilter) ([]*ManualPayout, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	payouts := make([]*ManualPayout, 0)
+	for _, payout := range s.payouts {
+		if filter.TenantID != "" && payout.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.Status != "" && payout.Status != filter.Status {
+			continue
+		}
+		copied := *payout
+		payouts = append(payouts, &copied)
+	}
+	sort.Slice(payouts, func(i, j int) bool { return payouts[i].CreatedAt.Before(payouts[j].CreatedAt) })
+	return payouts, nil
+}
+
+// AppendManualPayoutAudit acrescenta uma cópia do registo à cadeia do tenant
+func (s *InMemoryManualPayoutStore) AppendManualPayoutAudit(ctx context.Context, entry *ManualPayoutAuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chain := s.audit[entry.TenantID]
+	if entry.Sequence != int64(len(chain)+1) {
+		return ErrManualPayoutAuditConflict
+	}
+	copied := *entry
+	s.audit[entry.TenantID] = append(chain, &copied)
+	return nil
+}
+
+// LastManualPayoutAudit retorna uma cópia do último registo da cadeia do tenant
+func (s *InMemoryManualPayoutStore) LastManualPayoutAudit(ctx context.Context, tenantID string) (*ManualPayoutAuditEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	chain := s.audit[tenantID]
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	copied := *chain[len(chain)-1]
+	return &copied, nil
+}
+
+// ListManualPayoutAudit retorna cópias dos registos por sequência
+func (s *InMemoryManualPayoutStore) ListManualPayoutAudit(ctx context.Context, tenantID, payoutID string) ([]*ManualPayoutAuditEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := make([]*ManualPayoutAuditEntry, 0)
+	for _, entry := range s.audit[tenantID] {
+		if payoutID != "" && entry.PayoutID != payoutID {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
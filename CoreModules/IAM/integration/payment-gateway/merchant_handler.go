@@ -0,0 +1,215 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MerchantHandler expõe a API HTTP de registo e KYC de comerciantes
+type MerchantHandler struct {
+	service *MerchantService
+}
+
+// NewMerchantHandler cria uma nova instância do MerchantHandler
+func NewMerchantHandler(service *MerchantService) *MerchantHandler {
+	return &MerchantHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *MerchantHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/merchants", h.OnboardMerchant).Methods(http.MethodPost)
+	router.HandleFunc("/merchants", h.ListMerchants).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}", h.GetMerchant).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}/status", h.ChangeMerchantStatus).Methods(http.MethodPut)
+	router.HandleFunc("/merchants/{merchantId}/limits", h.UpdateMerchantLimits).Methods(http.MethodPut)
+	router.HandleFunc("/merchants/{merchantId}/kyc-documents", h.UploadKYCDocument).Methods(http.MethodPost)
+	router.HandleFunc("/merchants/{merchantId}/kyc-documents", h.ListKYCDocuments).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}/kyc-documents/{documentId}/review", h.ReviewKYCDocument).Methods(http.MethodPut)
+	router.HandleFunc("/merchants/{merchantId}/risk-metrics", h.GetRiskMetrics).Methods(http.MethodGet)
+}
+
+// merchantLimitsRequest representa a requisição de atualização de limites
+type merchantLimitsRequest struct {
+	Limits                MerchantLimits `json:"limits"`
+	SupportedPaymentTypes []string       `json:"supported_payment_types,omitempty"`
+}
+
+// kycReviewRequest representa a requisição de revisão de um documento KYC
+type kycReviewRequest struct {
+	Approved   bool   `json:"approved"`
+	ReviewerID string `json:"reviewer_id"`
+	Notes      string `json:"notes"`
+}
+
+// OnboardMerchant trata o registo de um novo comerciante
+func (h *MerchantHandler) OnboardMerchant(w http.ResponseWriter, r *http.Request) {
+	var req MerchantOnboardingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	merchant, err := h.service.OnboardMerchant(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, merchant)
+}
+
+// GetMerchant trata a consulta de um comerciante
+func (h *MerchantHandler) GetMerchant(w http.ResponseWriter, r *http.Request) {
+	merchant, err := h.service.GetMerchant(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, merchant)
+}
+
+// ListMerchants trata a listagem de comerciantes do tenant
+func (h *MerchantHandler) ListMerchants(w http.ResponseWriter, r *http.Request) {
+	status := MerchantStatus(r.URL.Query().Get("status"))
+
+	merchants, err := h.service.ListMerchants(r.Context(), r.Header.Get("X-Tenant-ID"), status)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, merchants)
+}
+
+// ChangeMerchantStatus trata a alteração de status de um comerciante
+func (h *MerchantHandler) ChangeMerchantStatus(w http.ResponseWriter, r *http.Request) {
+	var req MerchantStatusChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	if req.ActorID == "" {
+		req.ActorID = r.Header.Get("X-User-ID")
+	}
+
+	merchant, err := h.service.ChangeMerchantStatus(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"], &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, merchant)
+}
+
+// UpdateMerchantLimits trata a atualização de limites e tipos de pagamento
+func (h *MerchantHandler) UpdateMerchantLimits(w http.ResponseWriter, r *http.Request) {
+	var req merchantLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	merchant, err := h.service.UpdateMerchantLimits(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"], req.Limits, req.SupportedPaymentTypes)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, merchant)
+}
+
+// UploadKYCDocument trata o envio de um documento KYC (multipart/form-data)
+func (h *MerchantHandler) UploadKYCDocument(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxKYCDocumentSizeBytes+(1<<20))
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formulário multipart inválido ou demasiado grande")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Campo 'file' é obrigatório")
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.service.UploadKYCDocument(r.Context(),
+		r.Header.Get("X-Tenant-ID"),
+		mux.Vars(r)["merchantId"],
+		r.FormValue("document_type"),
+		header.Filename,
+		header.Header.Get("Content-Type"),
+		file)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, doc)
+}
+
+// ListKYCDocuments trata a listagem de documentos KYC de um comerciante
+func (h *MerchantHandler) ListKYCDocuments(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.service.ListKYCDocuments(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, docs)
+}
+
+// ReviewKYCDocument trata a revisão de um documento KYC
+func (h *MerchantHandler) ReviewKYCDocument(w http.ResponseWriter, r *http.Request) {
+	var req kycReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	if req.ReviewerID == "" {
+		req.ReviewerID = r.Header.Get("X-User-ID")
+	}
+
+	vars := mux.Vars(r)
+	doc, err := h.service.ReviewKYCDocument(r.Context(), r.Header.Get("X-Tenant-ID"), vars["merchantId"], vars["documentId"], req.Approved, req.ReviewerID, req.Notes)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// GetRiskMetrics trata a consulta das métricas de risco e compliance do comerciante
+func (h *MerchantHandler) GetRiskMetrics(w http.ResponseWriter, r *http.Request) {
+	riskMetrics, err := h.service.GetRiskMetrics(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, riskMetrics)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *MerchantHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrMerchantNotFound):
+		respondWithError(w, http.StatusNotFound, "merchant_not_found", err.Error())
+	case errors.Is(err, ErrMerchantAlreadyExists):
+		respondWithError(w, http.StatusConflict, "merchant_already_exists", err.Error())
+	case errors.Is(err, ErrInvalidMerchantTransition):
+		respondWithError(w, http.StatusConflict, "invalid_status_transition", err.Error())
+	case errors.Is(err, ErrKYCIncomplete):
+		respondWithError(w, http.StatusUnprocessableEntity, "kyc_incomplete", err.Error())
+	case errors.Is(err, ErrKYCDocumentTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, "kyc_document_too_large", err.Error())
+	default:
+		respondWithError(w, http.StatusBadRequest, "merchant_error", err.Error())
+	}
+}
@@ -0,0 +1,157 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// MerchantStatus representa o estado do ciclo de vida de um comerciante
+type MerchantStatus string
+
+const (
+	// Status de comerciante
+	MerchantStatusPending   MerchantStatus = "pending"
+	MerchantStatusActive    MerchantStatus = "active"
+	MerchantStatusSuspended MerchantStatus = "suspended"
+
+	// Status de documentos KYC
+	KYCDocumentStatusPending  = "pending"
+	KYCDocumentStatusApproved = "approved"
+	KYCDocumentStatusRejected = "rejected"
+
+	// Tamanho máximo de um documento KYC (10 MiB)
+	MaxKYCDocumentSizeBytes = 10 << 20
+)
+
+// Erros do subsistema de comerciantes
+var (
+	ErrMerchantNotFound          = errors.New("comerciante não encontrado")
+	ErrMerchantAlreadyExists     = errors.New("comerciante já registado")
+	ErrMerchantNotActive         = errors.New("comerciante não está ativo")
+	ErrInvalidMerchantTransition = errors.New("transição de status de comerciante inválida")
+	ErrKYCIncomplete             = errors.New("documentação KYC incompleta")
+	ErrPaymentTypeNotSupported   = errors.New("tipo de pagamento não suportado pelo comerciante")
+	ErrKYCDocumentTooLarge       = errors.New("documento KYC excede o tamanho máximo")
+)
+
+// merchantTransitions define as transições de status permitidas
+var merchantTransitions = map[MerchantStatus][]MerchantStatus{
+	MerchantStatusPending:   {MerchantStatusActive, MerchantStatusSuspended},
+	MerchantStatusActive:    {MerchantStatusSuspended},
+	MerchantStatusSuspended: {MerchantStatusActive},
+}
+
+// CanTransitionTo verifica se a transição para o status indicado é permitida
+func (s MerchantStatus) CanTransitionTo(target MerchantStatus) bool {
+	for _, allowed := range merchantTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Merchant representa um comerciante registado no gateway de pagamentos
+type Merchant struct {
+	MerchantID            string                 `json:"merchant_id"`
+	TenantID              string                 `json:"tenant_id"`
+	LegalName             string                 `json:"legal_name"`
+	TradeName             string                 `json:"trade_name"`
+	TaxID                 string                 `json:"tax_id"`
+	RegionCode            string                 `json:"region_code"`
+	MerchantCategory      string                 `json:"merchant_category"`
+	ContactEmail          string                 `json:"contact_email"`
+	ContactPhone          string                 `json:"contact_phone"`
+	Status                MerchantStatus         `json:"status"`
+	StatusReason          string                 `json:"status_reason,omitempty"`
+	SupportedPaymentTypes []string               `json:"supported_payment_types"`
+	Limits                MerchantLimits         `json:"limits"`
+	RequiredKYCDocuments  []string               `json:"required_kyc_documents"`
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+	ActivatedAt           *time.Time             `json:"activated_at,omitempty"`
+	SuspendedAt           *time.Time             `json:"suspended_at,omitempty"`
+}
+
+// MerchantLimits define os limites operacionais de um comerciante
+type MerchantLimits struct {
+	SingleTransactionMax  float64 `json:"single_transaction_max"`
+	DailyVolumeMax        float64 `json:"daily_volume_max"`
+	MonthlyVolumeMax      float64 `json:"monthly_volume_max"`
+	DailyTransactionCount int     `json:"daily_transaction_count"`
+	Currency              string  `json:"currency"`
+}
+
+// SupportsPaymentType verifica se o comerciante aceita o tipo de pagamento indicado
+func (m *Merchant) SupportsPaymentType(paymentType string) bool {
+	// Sem restrições configuradas, todos os tipos são aceites
+	if len(m.SupportedPaymentTypes) == 0 {
+		return true
+	}
+
+	for _, t := range m.SupportedPaymentTypes {
+		if t == paymentType {
+			return true
+		}
+	}
+	return false
+}
+
+// KYCDocument representa um documento KYC submetido por um comerciante
+type KYCDocument struct {
+	DocumentID   string    `json:"document_id" db:"document_id"`
+	MerchantID   string    `json:"merchant_id" db:"merchant_id"`
+	DocumentType string    `json:"document_type" db:"document_type"`
+	FileName     string    `json:"file_name" db:"file_name"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	SHA256       string    `json:"sha256" db:"sha256"`
+	StorageKey   string    `json:"storage_key" db:"storage_key"`
+	Status       string    `json:"status" db:"status"`
+	ReviewedBy   string    `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNotes  string    `json:"review_notes,omitempty" db:"review_notes"`
+	UploadedAt   time.Time `json:"uploaded_at" db:"uploaded_at"`
+}
+
+// MerchantRiskMetrics contém métricas de risco e compliance de um comerciante
+type MerchantRiskMetrics struct {
+	MerchantID        string    `json:"merchant_id" db:"merchant_id"`
+	TotalTransactions int64     `json:"total_transactions" db:"total_transactions"`
+	ApprovedCount     int64     `json:"approved_count" db:"approved_count"`
+	DeniedCount       int64     `json:"denied_count" db:"denied_count"`
+	ChallengedCount   int64     `json:"challenged_count" db:"challenged_count"`
+	ChargebackCount   int64     `json:"chargeback_count" db:"chargeback_count"`
+	AnomalyCount      int64     `json:"anomaly_count" db:"anomaly_count"`
+	TotalVolume       float64   `json:"total_volume" db:"total_volume"`
+	AverageTrustScore float64   `json:"average_trust_score" db:"average_trust_score"`
+	ComplianceFlags   int64     `json:"compliance_flags" db:"compliance_flags"`
+	LastTransactionAt time.Time `json:"last_transaction_at" db:"last_transaction_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	ApprovalRate      float64   `json:"approval_rate" db:"-"`
+	ChargebackRate    float64   `json:"chargeback_rate" db:"-"`
+	RiskLevel         string    `json:"risk_level" db:"-"`
+}
+
+// MerchantOnboardingRequest contém os dados para registo de um novo comerciante
+type MerchantOnboardingRequest struct {
+	MerchantID            string                 `json:"merchant_id,omitempty"`
+	TenantID              string                 `json:"tenant_id"`
+	LegalName             string                 `json:"legal_name"`
+	TradeName             string                 `json:"trade_name"`
+	TaxID                 string                 `json:"tax_id"`
+	RegionCode            string                 `json:"region_code"`
+	MerchantCategory      string                 `json:"merchant_category"`
+	ContactEmail          string                 `json:"contact_email"`
+	ContactPhone          string                 `json:"contact_phone"`
+	SupportedPaymentTypes []string               `json:"supported_payment_types"`
+	Limits                MerchantLimits         `json:"limits"`
+	Metadata              map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MerchantStatusChangeRequest contém os dados para alteração de status de um comerciante
+type MerchantStatusChangeRequest struct {
+	Status  MerchantStatus `json:"status"`
+	Reason  string         `json:"reason"`
+	ActorID string         `json:"actor_id"`
+}
@@ -0,0 +1,386 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// MerchantRepository define a interface de persistência de comerciantes
+type MerchantRepository interface {
+	// CreateMerchant persiste um novo comerciante
+	CreateMerchant(ctx context.Context, merchant *Merchant) error
+
+	// GetMerchant recupera um comerciante pelo ID
+	GetMerchant(ctx context.Context, tenantID, merchantID string) (*Merchant, error)
+
+	// ListMerchants lista os comerciantes de um tenant, opcionalmente filtrados por status
+	ListMerchants(ctx context.Context, tenantID string, status MerchantStatus) ([]*Merchant, error)
+
+	// UpdateMerchantStatus atualiza o status de um comerciante
+	UpdateMerchantStatus(ctx context.Context, merchant *Merchant) error
+
+	// UpdateMerchantLimits atualiza limites e tipos de pagamento suportados
+	UpdateMerchantLimits(ctx context.Context, merchant *Merchant) error
+
+	// SaveKYCDocument persiste os metadados de um documento KYC
+	SaveKYCDocument(ctx context.Context, doc *KYCDocument) error
+
+	// UpdateKYCDocument atualiza o resultado da revisão de um documento KYC
+	UpdateKYCDocument(ctx context.Context, doc *KYCDocument) error
+
+	// ListKYCDocuments lista os documentos KYC de um comerciante
+	ListKYCDocuments(ctx context.Context, merchantID string) ([]*KYCDocument, error)
+
+	// GetRiskMetrics recupera as métricas de risco de um comerciante
+	GetRiskMetrics(ctx context.Context, merchantID string) (*MerchantRiskMetrics, error)
+
+	// SaveRiskMetrics persiste as métricas de risco de um comerciante
+	SaveRiskMetrics(ctx context.Context, metrics *MerchantRiskMetrics) error
+}
+
+// PostgresMerchantRepository implementa MerchantRepository para PostgreSQL
+type PostgresMerchantRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresMerchantRepository cria uma nova instância de PostgresMerchantRepository
+func NewPostgresMerchantRepository(db *sqlx.DB) *PostgresMerchantRepository {
+	return &PostgresMerchantRepository{db: db}
+}
+
+// dbMerchant é a representação do comerciante na base de dados
+type dbMerchant struct {
+	MerchantID            string         `db:"merchant_id"`
+	TenantID              string         `db:"tenant_id"`
+	LegalName             string         `db:"legal_name"`
+	TradeName             string         `db:"trade_name"`
+	TaxID                 string         `db:"tax_id"`
+	RegionCode            string         `db:"region_code"`
+	MerchantCategory      string         `db:"merchant_category"`
+	ContactEmail          string         `db:"contact_email"`
+	ContactPhone          string         `db:"contact_phone"`
+	Status                string         `db:"status"`
+	StatusReason          sql.NullString `db:"status_reason"`
+	SupportedPaymentTypes pq.StringArray `db:"supported_payment_types"`
+	RequiredKYCDocuments  pq.StringArray `db:"required_kyc_documents"`
+	Limits                []byte         `db:"limits"`
+	Metadata              []byte         `db:"metadata"`
+	CreatedAt             time.Time      `db:"created_at"`
+	UpdatedAt             time.Time      `db:"updated_at"`
+	ActivatedAt           sql.NullTime   `db:"activated_at"`
+	SuspendedAt           sql.NullTime   `db:"suspended_at"`
+}
+
+// toMerchant converte um dbMerchant para Merchant
+func (dm *dbMerchant) toMerchant() (*Merchant, error) {
+	merchant := &Merchant{
+		MerchantID:            dm.MerchantID,
+		TenantID:              dm.TenantID,
+		LegalName:             dm.LegalName,
+		TradeName:             dm.TradeName,
+		TaxID:                 dm.TaxID,
+		RegionCode:            dm.RegionCode,
+		MerchantCategory:      dm.MerchantCategory,
+		ContactEmail:          dm.ContactEmail,
+		ContactPhone:          dm.ContactPhone,
+		Status:                MerchantStatus(dm.Status),
+		SupportedPaymentTypes: []string(dm.SupportedPaymentTypes),
+		RequiredKYCDocuments:  []string(dm.RequiredKYCDocuments),
+		CreatedAt:             dm.CreatedAt,
+		UpdatedAt:             dm.UpdatedAt,
+	}
+
+	if dm.StatusReason.Valid {
+		merchant.StatusReason = dm.StatusReason.String
+	}
+
+	if dm.ActivatedAt.Valid {
+		activatedAt := dm.ActivatedAt.Time
+		merchant.ActivatedAt = &activatedAt
+	}
+
+	if dm.SuspendedAt.Valid {
+		suspendedAt := dm.SuspendedAt.Time
+		merchant.SuspendedAt = &suspendedAt
+	}
+
+	if len(dm.Limits) > 0 {
+		if err := json.Unmarshal(dm.Limits, &merchant.Limits); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar limites do comerciante: %w", err)
+		}
+	}
+
+	if len(dm.Metadata) > 0 {
+		if err := json.Unmarshal(dm.Metadata, &merchant.Metadata); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar metadados do comerciante: %w", err)
+		}
+	}
+
+	return merchant, nil
+}
+
+// fromMerchant converte um Merchant para dbMerchant
+func fromMerchant(m *Merchant) (*dbMerchant, error) {
+	limits, err := json.Marshal(m.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao codificar limites do comerciante: %w", err)
+	}
+
+	metadata, err := json.Marshal(m.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao codificar metadados do comerciante: %w", err)
+	}
+
+	dm := &dbMerchant{
+		MerchantID:            m.MerchantID,
+		TenantID:              m.TenantID,
+		LegalName:             m.LegalName,
+		TradeName:             m.TradeName,
+		TaxID:                 m.TaxID,
+		RegionCode:            m.RegionCode,
+		MerchantCategory:      m.MerchantCategory,
+		ContactEmail:          m.ContactEmail,
+		ContactPhone:          m.ContactPhone,
+		Status:                string(m.Status),
+		SupportedPaymentTypes: pq.StringArray(m.SupportedPaymentTypes),
+		RequiredKYCDocuments:  pq.StringArray(m.RequiredKYCDocuments),
+		Limits:                limits,
+		Metadata:              metadata,
+		CreatedAt:             m.CreatedAt,
+		UpdatedAt:             m.UpdatedAt,
+	}
+
+	if m.StatusReason != "" {
+		dm.StatusReason = sql.NullString{String: m.StatusReason, Valid: true}
+	}
+
+	if m.ActivatedAt != nil {
+		dm.ActivatedAt = sql.NullTime{Time: *m.ActivatedAt, Valid: true}
+	}
+
+	if m.SuspendedAt != nil {
+		dm.SuspendedAt = sql.NullTime{Time: *m.SuspendedAt, Valid: true}
+	}
+
+	return dm, nil
+}
+
+// CreateMerchant persiste um novo comerciante
+func (r *PostgresMerchantRepository) CreateMerchant(ctx context.Context, merchant *Merchant) error {
+	dm, err := fromMerchant(merchant)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.merchants (
+			merchant_id, tenant_id, legal_name, trade_name, tax_id,
+			region_code, merchant_category, contact_email, contact_phone,
+			status, status_reason, supported_payment_types, required_kyc_documents,
+			limits, metadata, created_at, updated_at
+		) VALUES (
+			:merchant_id, :tenant_id, :legal_name, :trade_name, :tax_id,
+			:region_code, :merchant_category, :contact_email, :contact_phone,
+			:status, :status_reason, :supported_payment_types, :required_kyc_documents,
+			:limits, :metadata, :created_at, :updated_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, dm); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrMerchantAlreadyExists
+		}
+		return fmt.Errorf("falha ao salvar comerciante: %w", err)
+	}
+
+	return nil
+}
+
+// GetMerchant recupera um comerciante pelo ID
+func (r *PostgresMerchantRepository) GetMerchant(ctx context.Context, tenantID, merchantID string) (*Merchant, error) {
+	var dm dbMerchant
+	query := `SELECT * FROM payment_gateway.merchants WHERE tenant_id = $1 AND merchant_id = $2`
+	if err := r.db.GetContext(ctx, &dm, query, tenantID, merchantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar comerciante: %w", err)
+	}
+
+	return dm.toMerchant()
+}
+
+// ListMerchants lista os comerciantes de um tenant, opcionalmente filtrados por status
+func (r *PostgresMerchantRepository) ListMerchants(ctx context.Context, tenantID string, status MerchantStatus) ([]*Merchant, error) {
+	var rows []dbMerchant
+	query := `SELECT * FROM payment_gateway.merchants WHERE tenant_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at DESC`
+	if err := r.db.SelectContext(ctx, &rows, query, tenantID, string(status)); err != nil {
+		return nil, fmt.Errorf("falha ao listar comerciantes: %w", err)
+	}
+
+	merchants := make([]*Merchant, 0, len(rows))
+	for i := range rows {
+		merchant, err := rows[i].toMerchant()
+		if err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, merchant)
+	}
+
+	return merchants, nil
+}
+
+// UpdateMerchantStatus atualiza o status de um comerciante
+func (r *PostgresMerchantRepository) UpdateMerchantStatus(ctx context.Context, merchant *Merchant) error {
+	dm, err := fromMerchant(merchant)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE payment_gateway.merchants SET
+			status = :status,
+			status_reason = :status_reason,
+			activated_at = :activated_at,
+			suspended_at = :suspended_at,
+			updated_at = :updated_at
+		WHERE tenant_id = :tenant_id AND merchant_id = :merchant_id
+	`
+
+	return r.execExpectingRow(ctx, query, dm)
+}
+
+// UpdateMerchantLimits atualiza limites e tipos de pagamento suportados
+func (r *PostgresMerchantRepository) UpdateMerchantLimits(ctx context.Context, merchant *Merchant) error {
+	dm, err := fromMerchant(merchant)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE payment_gateway.merchants SET
+			limits = :limits,
+			supported_payment_types = :supported_payment_types,
+			updated_at = :updated_at
+		WHERE tenant_id = :tenant_id AND merchant_id = :merchant_id
+	`
+
+	return r.execExpectingRow(ctx, query, dm)
+}
+
+// SaveKYCDocument persiste os metadados de um documento KYC
+func (r *PostgresMerchantRepository) SaveKYCDocument(ctx context.Context, doc *KYCDocument) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_kyc_documents (
+			document_id, merchant_id, document_type, file_name, content_type,
+			size_bytes, sha256, storage_key, status, reviewed_by, review_notes, uploaded_at
+		) VALUES (
+			:document_id, :merchant_id, :document_type, :file_name, :content_type,
+			:size_bytes, :sha256, :storage_key, :status, :reviewed_by, :review_notes, :uploaded_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, doc); err != nil {
+		return fmt.Errorf("falha ao salvar documento KYC: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateKYCDocument atualiza o resultado da revisão de um documento KYC
+func (r *PostgresMerchantRepository) UpdateKYCDocument(ctx context.Context, doc *KYCDocument) error {
+	query := `
+		UPDATE payment_gateway.merchant_kyc_documents SET
+			status = :status,
+			reviewed_by = :reviewed_by,
+			review_notes = :review_notes
+		WHERE document_id = :document_id AND merchant_id = :merchant_id
+	`
+
+	return r.execExpectingRow(ctx, query, doc)
+}
+
+// ListKYCDocuments lista os documentos KYC de um comerciante
+func (r *PostgresMerchantRepository) ListKYCDocuments(ctx context.Context, merchantID string) ([]*KYCDocument, error) {
+	var docs []*KYCDocument
+	query := `SELECT * FROM payment_gateway.merchant_kyc_documents WHERE merchant_id = $1 ORDER BY uploaded_at`
+	if err := r.db.SelectContext(ctx, &docs, query, merchantID); err != nil {
+		return nil, fmt.Errorf("falha ao listar documentos KYC: %w", err)
+	}
+
+	return docs, nil
+}
+
+// GetRiskMetrics recupera as métricas de risco de um comerciante
+func (r *PostgresMerchantRepository) GetRiskMetrics(ctx context.Context, merchantID string) (*MerchantRiskMetrics, error) {
+	var metrics MerchantRiskMetrics
+	query := `SELECT * FROM payment_gateway.merchant_risk_metrics WHERE merchant_id = $1`
+	if err := r.db.GetContext(ctx, &metrics, query, merchantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &MerchantRiskMetrics{MerchantID: merchantID}, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar métricas de risco: %w", err)
+	}
+
+	return &metrics, nil
+}
+
+// SaveRiskMetrics persiste as métricas de risco de um comerciante
+func (r *PostgresMerchantRepository) SaveRiskMetrics(ctx context.Context, metrics *MerchantRiskMetrics) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_risk_metrics (
+			merchant_id, total_transactions, approved_count, denied_count, challenged_count,
+			chargeback_count, anomaly_count, total_volume, average_trust_score,
+			compliance_flags, last_transaction_at, updated_at
+		) VALUES (
+			:merchant_id, :total_transactions, :approved_count, :denied_count, :challenged_count,
+			:chargeback_count, :anomaly_count, :total_volume, :average_trust_score,
+			:compliance_flags, :last_transaction_at, :updated_at
+		)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			total_transactions = EXCLUDED.total_transactions,
+			approved_count = EXCLUDED.approved_count,
+			denied_count = EXCLUDED.denied_count,
+			challenged_count = EXCLUDED.challenged_count,
+			chargeback_count = EXCLUDED.chargeback_count,
+			anomaly_count = EXCLUDED.anomaly_count,
+			total_volume = EXCLUDED.total_volume,
+			average_trust_score = EXCLUDED.average_trust_score,
+			compliance_flags = EXCLUDED.compliance_flags,
+			last_transaction_at = EXCLUDED.last_transaction_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, metrics); err != nil {
+		return fmt.Errorf("falha ao salvar métricas de risco: %w", err)
+	}
+
+	return nil
+}
+
+// execExpectingRow executa uma atualização e retorna ErrMerchantNotFound se nenhuma linha for afetada
+func (r *PostgresMerchantRepository) execExpectingRow(ctx context.Context, query string, arg interface{}) error {
+	result, err := r.db.NamedExecContext(ctx, query, arg)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar comerciante: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+
+	if affected == 0 {
+		return ErrMerchantNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,678 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// Documentos KYC exigidos por padrão para ativação de um comerciante
+var defaultRequiredKYCDocuments = []string{
+	"business_registration",
+	"tax_certificate",
+	"legal_representative_id",
+	"proof_of_address",
+}
+
+// KYCDocumentStore define o armazenamento do conteúdo de documentos KYC
+type KYCDocumentStore interface {
+	// Put armazena o conteúdo do documento e retorna a chave de armazenamento
+	Put(ctx context.Context, key string, content io.Reader) (string, error)
+}
+
+// LocalKYCDocumentStore armazena documentos KYC no sistema de ficheiros local
+type LocalKYCDocumentStore struct {
+	baseDir string
+}
+
+// NewLocalKYCDocumentStore cria uma nova instância do armazenamento local
+func NewLocalKYCDocumentStore(baseDir string) (*LocalKYCDocumentStore, error) {
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, fmt.Errorf("falha ao criar diretório de documentos KYC: %w", err)
+	}
+	return &LocalKYCDocumentStore{baseDir: baseDir}, nil
+}
+
+// Put armazena o conteúdo do documento e retorna a chave de armazenamento
+func (s *LocalKYCDocumentStore) Put(ctx context.Context, key string, content io.Reader) (string, error) {
+	path := filepath.Join(s.baseDir, filepath.Clean("/"+key))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("falha ao criar diretório do documento: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("falha ao criar ficheiro do documento: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, content); err != nil {
+		return "", fmt.Errorf("falha ao gravar documento: %w", err)
+	}
+
+	return key, nil
+}
+
+// MerchantServiceConfig contém configurações do serviço de comerciantes
+type MerchantServiceConfig struct {
+	// Documentos KYC exigidos por região; a chave "default" aplica-se às restantes
+	RequiredKYCDocuments map[string][]string `json:"required_kyc_documents"`
+
+	// Limites padrão atribuídos no registo quando não informados
+	DefaultLimits MerchantLimits `json:"default_limits"`
+
+	// Taxa de chargeback a partir da qual o comerciante é considerado de alto risco
+	HighRiskChargebackRate float64 `json:"high_risk_chargeback_rate"`
+}
+
+// merchantUsage acumula o volume transacionado por um comerciante num período
+type merchantUsage struct {
+	day          string
+	month        string
+	dailyVolume  float64
+	dailyCount   int
+	monthlyValue float64
+}
+
+// MerchantService gerencia o registo, KYC, limites e métricas de comerciantes
+type MerchantService struct {
+	config          MerchantServiceConfig
+	repository      MerchantRepository
+	documentStore   KYCDocumentStore
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	usage           map[string]*merchantUsage
+	usageMutex      sync.Mutex
+
+	// riskMetricsMutex protege a atualização das métricas de risco
+	riskMetricsMutex sync.Mutex
+}
+
+// NewMerchantService cria uma nova instância do serviço de comerciantes
+func NewMerchantService(config MerchantServiceConfig, repository MerchantRepository, documentStore KYCDocumentStore) (*MerchantService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-merchant-service",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.HighRiskChargebackRate == 0 {
+		config.HighRiskChargebackRate = 0.01
+	}
+
+	return &MerchantService{
+		config:          config,
+		repository:      repository,
+		documentStore:   documentStore,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		usage:           make(map[string]*merchantUsage),
+	}, nil
+}
+
+// OnboardMerchant regista um novo comerciante com status pendente
+func (s *MerchantService) OnboardMerchant(ctx context.Context, req *MerchantOnboardingRequest) (*Merchant, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.OnboardMerchant")
+	defer span.End()
+
+	if err := validateOnboardingRequest(req); err != nil {
+		return nil, err
+	}
+
+	merchantID := req.MerchantID
+	if merchantID == "" {
+		merchantID = fmt.Sprintf("mer-%s", uuid.New().String())
+	}
+
+	limits := req.Limits
+	if limits == (MerchantLimits{}) {
+		limits = s.config.DefaultLimits
+	}
+
+	now := time.Now()
+	merchant := &Merchant{
+		MerchantID:            merchantID,
+		TenantID:              req.TenantID,
+		LegalName:             req.LegalName,
+		TradeName:             req.TradeName,
+		TaxID:                 req.TaxID,
+		RegionCode:            req.RegionCode,
+		MerchantCategory:      req.MerchantCategory,
+		ContactEmail:          req.ContactEmail,
+		ContactPhone:          req.ContactPhone,
+		Status:                MerchantStatusPending,
+		SupportedPaymentTypes: req.SupportedPaymentTypes,
+		Limits:                limits,
+		RequiredKYCDocuments:  s.requiredDocumentsFor(req.RegionCode),
+		Metadata:              req.Metadata,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+
+	if err := s.repository.CreateMerchant(ctx, merchant); err != nil {
+		s.logger.ErrorWithContext(ctx, "Erro ao registar comerciante",
+			"merchant_id", merchantID,
+			"tenant_id", req.TenantID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_merchants_onboarded_total", map[string]string{
+		"tenant_id": req.TenantID,
+		"region":    req.RegionCode,
+	})
+
+	s.logger.InfoWithContext(ctx, "Comerciante registado, aguardando KYC",
+		"merchant_id", merchantID,
+		"tenant_id", req.TenantID,
+		"required_documents", merchant.RequiredKYCDocuments)
+
+	return merchant, nil
+}
+
+// GetMerchant recupera um comerciante
+func (s *MerchantService) GetMerchant(ctx context.Context, tenantID, merchantID string) (*Merchant, error) {
+	return s.repository.GetMerchant(ctx, tenantID, merchantID)
+}
+
+// ListMerchants lista os comerciantes de um tenant
+func (s *MerchantService) ListMerchants(ctx context.Context, tenantID string, status MerchantStatus) ([]*Merchant, error) {
+	return s.repository.ListMerchants(ctx, tenantID, status)
+}
+
+// UploadKYCDocument armazena um documento KYC e regista os seus metadados
+func (s *MerchantService) UploadKYCDocument(ctx context.Context, tenantID, merchantID, documentType, fileName, contentType string, content io.Reader) (*KYCDocument, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.UploadKYCDocument")
+	defer span.End()
+
+	if documentType == "" {
+		return nil, fmt.Errorf("tipo de documento é obrigatório")
+	}
+
+	merchant, err := s.repository.GetMerchant(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	documentID := fmt.Sprintf("kyc-%s", uuid.New().String())
+	storageKey := fmt.Sprintf("%s/%s/%s", merchant.TenantID, merchant.MerchantID, documentID)
+
+	// Ler o conteúdo para memória e validar o tamanho antes de armazenar, para
+	// que documentos rejeitados não fiquem persistidos no armazenamento
+	var buffer bytes.Buffer
+	size, err := buffer.ReadFrom(io.LimitReader(content, MaxKYCDocumentSizeBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler documento KYC: %w", err)
+	}
+
+	if size > MaxKYCDocumentSizeBytes {
+		return nil, fmt.Errorf("%w: máximo de %d bytes", ErrKYCDocumentTooLarge, MaxKYCDocumentSizeBytes)
+	}
+
+	digest := sha256.Sum256(buffer.Bytes())
+	key, err := s.documentStore.Put(ctx, storageKey, &buffer)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao armazenar documento KYC: %w", err)
+	}
+
+	doc := &KYCDocument{
+		DocumentID:   documentID,
+		MerchantID:   merchant.MerchantID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(fileName),
+		ContentType:  contentType,
+		SizeBytes:    size,
+		SHA256:       hex.EncodeToString(digest[:]),
+		StorageKey:   key,
+		Status:       KYCDocumentStatusPending,
+		UploadedAt:   time.Now(),
+	}
+
+	if err := s.repository.SaveKYCDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Documento KYC recebido",
+		"merchant_id", merchant.MerchantID,
+		"document_id", documentID,
+		"document_type", documentType,
+		"size_bytes", doc.SizeBytes)
+
+	return doc, nil
+}
+
+// ListKYCDocuments lista os documentos KYC de um comerciante
+func (s *MerchantService) ListKYCDocuments(ctx context.Context, tenantID, merchantID string) ([]*KYCDocument, error) {
+	if _, err := s.repository.GetMerchant(ctx, tenantID, merchantID); err != nil {
+		return nil, err
+	}
+	return s.repository.ListKYCDocuments(ctx, merchantID)
+}
+
+// ReviewKYCDocument regista o resultado da revisão de um documento KYC
+func (s *MerchantService) ReviewKYCDocument(ctx context.Context, tenantID, merchantID, documentID string, approved bool, reviewerID, notes string) (*KYCDocument, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.ReviewKYCDocument")
+	defer span.End()
+
+	if _, err := s.repository.GetMerchant(ctx, tenantID, merchantID); err != nil {
+		return nil, err
+	}
+
+	docs, err := s.repository.ListKYCDocuments(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc *KYCDocument
+	for _, d := range docs {
+		if d.DocumentID == documentID {
+			doc = d
+			break
+		}
+	}
+
+	if doc == nil {
+		return nil, fmt.Errorf("documento KYC não encontrado: %s", documentID)
+	}
+
+	doc.Status = KYCDocumentStatusRejected
+	if approved {
+		doc.Status = KYCDocumentStatusApproved
+	}
+	doc.ReviewedBy = reviewerID
+	doc.ReviewNotes = notes
+
+	if err := s.repository.UpdateKYCDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Documento KYC revisto",
+		"merchant_id", merchantID,
+		"document_id", documentID,
+		"status", doc.Status,
+		"reviewed_by", reviewerID)
+
+	return doc, nil
+}
+
+// ChangeMerchantStatus aplica uma transição no ciclo de vida do comerciante
+func (s *MerchantService) ChangeMerchantStatus(ctx context.Context, tenantID, merchantID string, req *MerchantStatusChangeRequest) (*Merchant, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.ChangeMerchantStatus")
+	defer span.End()
+
+	merchant, err := s.repository.GetMerchant(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !merchant.Status.CanTransitionTo(req.Status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidMerchantTransition, merchant.Status, req.Status)
+	}
+
+	// A ativação exige que todos os documentos KYC obrigatórios estejam aprovados
+	if req.Status == MerchantStatusActive {
+		missing, err := s.missingKYCDocuments(ctx, merchant)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrKYCIncomplete, strings.Join(missing, ", "))
+		}
+	}
+
+	now := time.Now()
+	previous := merchant.Status
+	merchant.Status = req.Status
+	merchant.StatusReason = req.Reason
+	merchant.UpdatedAt = now
+
+	switch req.Status {
+	case MerchantStatusActive:
+		merchant.ActivatedAt = &now
+		merchant.SuspendedAt = nil
+	case MerchantStatusSuspended:
+		merchant.SuspendedAt = &now
+	}
+
+	if err := s.repository.UpdateMerchantStatus(ctx, merchant); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_merchant_status_changes_total", map[string]string{
+		"region": merchant.RegionCode,
+		"from":   string(previous),
+		"to":     string(req.Status),
+	})
+
+	s.logger.InfoWithContext(ctx, "Status do comerciante alterado",
+		"merchant_id", merchantID,
+		"from", previous,
+		"to", req.Status,
+		"actor_id", req.ActorID,
+		"reason", req.Reason)
+
+	return merchant, nil
+}
+
+// UpdateMerchantLimits atualiza os limites e os tipos de pagamento suportados
+func (s *MerchantService) UpdateMerchantLimits(ctx context.Context, tenantID, merchantID string, limits MerchantLimits, paymentTypes []string) (*Merchant, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.UpdateMerchantLimits")
+	defer span.End()
+
+	merchant, err := s.repository.GetMerchant(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.Limits = limits
+	if paymentTypes != nil {
+		merchant.SupportedPaymentTypes = paymentTypes
+	}
+	merchant.UpdatedAt = time.Now()
+
+	if err := s.repository.UpdateMerchantLimits(ctx, merchant); err != nil {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// ValidatePayment verifica se o comerciante pode aceitar o pagamento
+func (s *MerchantService) ValidatePayment(ctx context.Context, req *PaymentRequest) (*LimitCheckResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.ValidatePayment")
+	defer span.End()
+
+	merchant, err := s.repository.GetMerchant(ctx, req.TenantID, req.MerchantID)
+	if err != nil {
+		if errors.Is(err, ErrMerchantNotFound) {
+			return &LimitCheckResult{Allowed: false, Reason: "merchant_not_found", Details: err.Error()}, nil
+		}
+		return nil, err
+	}
+
+	if merchant.Status != MerchantStatusActive {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_not_active",
+			Details: fmt.Sprintf("Comerciante %s com status %s", merchant.MerchantID, merchant.Status),
+		}, nil
+	}
+
+	if !merchant.SupportsPaymentType(req.PaymentType) {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "payment_type_not_supported",
+			Details: fmt.Sprintf("Tipo de pagamento %s não suportado pelo comerciante", req.PaymentType),
+		}, nil
+	}
+
+	limits := merchant.Limits
+	if limits.Currency != "" && !strings.EqualFold(limits.Currency, req.Currency) {
+		// Limites expressos noutra moeda não são comparáveis sem conversão; o
+		// pagamento é recusado para que a moeda não sirva para contornar limites
+		s.logger.WarnWithContext(ctx, "Moeda do pagamento difere da moeda dos limites do comerciante",
+			"merchant_id", merchant.MerchantID,
+			"limits_currency", limits.Currency,
+			"currency", req.Currency)
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_limits_currency_mismatch",
+			Details: fmt.Sprintf("Moeda %s difere da moeda dos limites do comerciante (%s)", req.Currency, limits.Currency),
+		}, nil
+	}
+
+	if limits.SingleTransactionMax > 0 && req.Amount > limits.SingleTransactionMax {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_single_transaction_limit_exceeded",
+			Details: fmt.Sprintf("Valor %.2f excede o limite do comerciante (%.2f)", req.Amount, limits.SingleTransactionMax),
+		}, nil
+	}
+
+	usage := s.currentUsage(merchant.MerchantID)
+	if limits.DailyVolumeMax > 0 && usage.dailyVolume+req.Amount > limits.DailyVolumeMax {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_daily_limit_exceeded",
+			Details: fmt.Sprintf("Volume diário do comerciante excederia %.2f", limits.DailyVolumeMax),
+		}, nil
+	}
+
+	if limits.MonthlyVolumeMax > 0 && usage.monthlyValue+req.Amount > limits.MonthlyVolumeMax {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_monthly_limit_exceeded",
+			Details: fmt.Sprintf("Volume mensal do comerciante excederia %.2f", limits.MonthlyVolumeMax),
+		}, nil
+	}
+
+	if limits.DailyTransactionCount > 0 && usage.dailyCount+1 > limits.DailyTransactionCount {
+		return &LimitCheckResult{
+			Allowed: false,
+			Reason:  "merchant_daily_count_exceeded",
+			Details: fmt.Sprintf("Número diário de transações do comerciante excederia %d", limits.DailyTransactionCount),
+		}, nil
+	}
+
+	return &LimitCheckResult{Allowed: true}, nil
+}
+
+// RecordTransactionOutcome atualiza volume e métricas de risco após o processamento
+func (s *MerchantService) RecordTransactionOutcome(ctx context.Context, req *PaymentRequest, resp *PaymentResponse) {
+	ctx, span := s.tracer.StartSpan(ctx, "MerchantService.RecordTransactionOutcome")
+	defer span.End()
+
+	if resp.Status == TransactionStatusApproved {
+		s.addUsage(req.MerchantID, req.Amount)
+	}
+
+	// Serializar a leitura-modificação-escrita para não perder contagens concorrentes
+	s.riskMetricsMutex.Lock()
+	defer s.riskMetricsMutex.Unlock()
+
+	riskMetrics, err := s.repository.GetRiskMetrics(ctx, req.MerchantID)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao recuperar métricas de risco do comerciante",
+			"merchant_id", req.MerchantID,
+			"error", err.Error())
+		return
+	}
+
+	// Média móvel da pontuação de confiança
+	riskMetrics.AverageTrustScore = (riskMetrics.AverageTrustScore*float64(riskMetrics.TotalTransactions) +
+		float64(resp.TrustScore)) / float64(riskMetrics.TotalTransactions+1)
+	riskMetrics.TotalTransactions++
+
+	switch resp.Status {
+	case TransactionStatusApproved:
+		riskMetrics.ApprovedCount++
+		riskMetrics.TotalVolume += req.Amount
+	case TransactionStatusDenied:
+		riskMetrics.DeniedCount++
+	case TransactionStatusChallenged:
+		riskMetrics.ChallengedCount++
+	}
+
+	riskMetrics.AnomalyCount += int64(len(resp.DetectedAnomalies))
+	if hasCriticalAnomaly(resp.DetectedAnomalies) {
+		riskMetrics.ComplianceFlags++
+	}
+
+	now := time.Now()
+	riskMetrics.MerchantID = req.MerchantID
+	riskMetrics.LastTransactionAt = now
+	riskMetrics.UpdatedAt = now
+
+	if err := s.repository.SaveRiskMetrics(ctx, riskMetrics); err != nil {
+		s.logger.WarnWithContext(ctx, "Falha ao salvar métricas de risco do comerciante",
+			"merchant_id", req.MerchantID,
+			"error", err.Error())
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_merchant_transactions_total", map[string]string{
+		"merchant_id": req.MerchantID,
+		"status":      resp.Status,
+	})
+}
+
+// RecordChargeback regista um chargeback recebido para o comerciante
+func (s *MerchantService) RecordChargeback(ctx context.Context, merchantID string) error {
+	s.riskMetricsMutex.Lock()
+	defer s.riskMetricsMutex.Unlock()
+
+	riskMetrics, err := s.repository.GetRiskMetrics(ctx, merchantID)
+	if err != nil {
+		return err
+	}
+
+	riskMetrics.MerchantID = merchantID
+	riskMetrics.ChargebackCount++
+	riskMetrics.UpdatedAt = time.Now()
+
+	return s.repository.SaveRiskMetrics(ctx, riskMetrics)
+}
+
+// GetRiskMetrics retorna as métricas de risco e compliance consolidadas do comerciante
+func (s *MerchantService) GetRiskMetrics(ctx context.Context, tenantID, merchantID string) (*MerchantRiskMetrics, error) {
+	if _, err := s.repository.GetMerchant(ctx, tenantID, merchantID); err != nil {
+		return nil, err
+	}
+
+	riskMetrics, err := s.repository.GetRiskMetrics(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if riskMetrics.TotalTransactions > 0 {
+		riskMetrics.ApprovalRate = float64(riskMetrics.ApprovedCount) / float64(riskMetrics.TotalTransactions)
+	}
+	if riskMetrics.ApprovedCount > 0 {
+		riskMetrics.ChargebackRate = float64(riskMetrics.ChargebackCount) / float64(riskMetrics.ApprovedCount)
+	}
+
+	switch {
+	case riskMetrics.ChargebackRate >= s.config.HighRiskChargebackRate || riskMetrics.ComplianceFlags > 0:
+		riskMetrics.RiskLevel = "high"
+	case riskMetrics.TotalTransactions > 0 && riskMetrics.AverageTrustScore < 60:
+		riskMetrics.RiskLevel = "medium"
+	default:
+		riskMetrics.RiskLevel = "low"
+	}
+
+	return riskMetrics, nil
+}
+
+// missingKYCDocuments retorna os documentos obrigatórios ainda não aprovados
+func (s *MerchantService) missingKYCDocuments(ctx context.Context, merchant *Merchant) ([]string, error) {
+	docs, err := s.repository.ListKYCDocuments(ctx, merchant.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := make(map[string]bool)
+	for _, doc := range docs {
+		if doc.Status == KYCDocumentStatusApproved {
+			approved[doc.DocumentType] = true
+		}
+	}
+
+	missing := []string{}
+	for _, required := range merchant.RequiredKYCDocuments {
+		if !approved[required] {
+			missing = append(missing, required)
+		}
+	}
+
+	return missing, nil
+}
+
+// requiredDocumentsFor retorna a lista de documentos KYC exigidos para a região
+func (s *MerchantService) requiredDocumentsFor(regionCode string) []string {
+	if docs, ok := s.config.RequiredKYCDocuments[regionCode]; ok {
+		return docs
+	}
+	if docs, ok := s.config.RequiredKYCDocuments["default"]; ok {
+		return docs
+	}
+	return defaultRequiredKYCDocuments
+}
+
+// currentUsage retorna o volume acumulado do comerciante no dia e mês correntes
+func (s *MerchantService) currentUsage(merchantID string) merchantUsage {
+	s.usageMutex.Lock()
+	defer s.usageMutex.Unlock()
+
+	return *s.usageFor(merchantID)
+}
+
+// addUsage acumula o valor de uma transação aprovada
+func (s *MerchantService) addUsage(merchantID string, amount float64) {
+	s.usageMutex.Lock()
+	defer s.usageMutex.Unlock()
+
+	usage := s.usageFor(merchantID)
+	usage.dailyVolume += amount
+	usage.dailyCount++
+	usage.monthlyValue += amount
+}
+
+// usageFor retorna o acumulador do comerciante, reiniciando-o na mudança de período
+func (s *MerchantService) usageFor(merchantID string) *merchantUsage {
+	now := time.Now()
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+
+	usage, ok := s.usage[merchantID]
+	if !ok {
+		usage = &merchantUsage{day: day, month: month}
+		s.usage[merchantID] = usage
+	}
+
+	if usage.month != month {
+		usage.month = month
+		usage.monthlyValue = 0
+	}
+
+	if usage.day != day {
+		usage.day = day
+		usage.dailyVolume = 0
+		usage.dailyCount = 0
+	}
+
+	return usage
+}
+
+// validateOnboardingRequest valida os campos obrigatórios do registo
+func validateOnboardingRequest(req *MerchantOnboardingRequest) error {
+	if req.TenantID == "" {
+		return fmt.Errorf("tenant_id é obrigatório")
+	}
+	if req.LegalName == "" {
+		return fmt.Errorf("legal_name é obrigatório")
+	}
+	if req.TaxID == "" {
+		return fmt.Errorf("tax_id é obrigatório")
+	}
+	if req.RegionCode == "" {
+		return fmt.Errorf("region_code é obrigatório")
+	}
+	return nil
+}
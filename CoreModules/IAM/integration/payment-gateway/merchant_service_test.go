@@ -0,0 +1,216 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMerchantRepository implementa MerchantRepository em memória
+type fakeMerchantRepository struct {
+	mu          sync.Mutex
+	merchants   map[string]*Merchant
+	documents   map[string][]*KYCDocument
+	riskMetrics map[string]MerchantRiskMetrics
+}
+
+func newFakeMerchantRepository() *fakeMerchantRepository {
+	return &fakeMerchantRepository{
+		merchants:   make(map[string]*Merchant),
+		documents:   make(map[string][]*KYCDocument),
+		riskMetrics: make(map[string]MerchantRiskMetrics),
+	}
+}
+
+func (r *fakeMerchantRepository) CreateMerchant(ctx context.Context, merchant *Merchant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.merchants[merchant.MerchantID]; ok {
+		return ErrMerchantAlreadyExists
+	}
+	copied := *merchant
+	r.merchants[merchant.MerchantID] = &copied
+	return nil
+}
+
+func (r *fakeMerchantRepository) GetMerchant(ctx context.Context, tenantID, merchantID string) (*Merchant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	merchant, ok := r.merchants[merchantID]
+	if !ok || merchant.TenantID != tenantID {
+		return nil, ErrMerchantNotFound
+	}
+	copied := *merchant
+	return &copied, nil
+}
+
+func (r *fakeMerchantRepository) ListMerchants(ctx context.Context, tenantID string, status MerchantStatus) ([]*Merchant, error) {
+	return nil, nil
+}
+
+func (r *fakeMerchantRepository) UpdateMerchantStatus(ctx context.Context, merchant *Merchant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *merchant
+	r.merchants[merchant.MerchantID] = &copied
+	return nil
+}
+
+func (r *fakeMerchantRepository) UpdateMerchantLimits(ctx context.Context, merchant *Merchant) error {
+	return r.UpdateMerchantStatus(ctx, merchant)
+}
+
+func (r *fakeMerchantRepository) SaveKYCDocument(ctx context.Context, doc *KYCDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.documents[doc.MerchantID] = append(r.documents[doc.MerchantID], doc)
+	return nil
+}
+
+func (r *fakeMerchantRepository) UpdateKYCDocument(ctx context.Context, doc *KYCDocument) error {
+	return nil
+}
+
+func (r *fakeMerchantRepository) ListKYCDocuments(ctx context.Context, merchantID string) ([]*KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.documents[merchantID], nil
+}
+
+func (r *fakeMerchantRepository) GetRiskMetrics(ctx context.Context, merchantID string) (*MerchantRiskMetrics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	metrics := r.riskMetrics[merchantID]
+	return &metrics, nil
+}
+
+func (r *fakeMerchantRepository) SaveRiskMetrics(ctx context.Context, metrics *MerchantRiskMetrics) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.riskMetrics[metrics.MerchantID] = *metrics
+	return nil
+}
+
+// fakeKYCDocumentStore regista as chaves armazenadas
+type fakeKYCDocumentStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeKYCDocumentStore) Put(ctx context.Context, key string, content io.Reader) (string, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return key, nil
+}
+
+// newTestMerchantService cria o serviço com um comerciante ativo com limites em EUR
+func newTestMerchantService(t *testing.T) (*MerchantService, *fakeMerchantRepository, *fakeKYCDocumentStore) {
+	t.Helper()
+	repository := newFakeMerchantRepository()
+	store := &fakeKYCDocumentStore{objects: make(map[string][]byte)}
+
+	service, err := NewMerchantService(MerchantServiceConfig{}, repository, store)
+	require.NoError(t, err)
+
+	require.NoError(t, repository.CreateMerchant(context.Background(), &Merchant{
+		MerchantID: "mer-1",
+		TenantID:   "tenant-1",
+		Status:     MerchantStatusActive,
+		Limits: MerchantLimits{
+			SingleTransactionMax:  1000,
+			DailyVolumeMax:        5000,
+			DailyTransactionCount: 10,
+			Currency:              "EUR",
+		},
+	}))
+
+	return service, repository, store
+}
+
+func TestValidatePaymentEnforcesLimitsInMerchantCurrency(t *testing.T) {
+	service, _, _ := newTestMerchantService(t)
+
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		allowed  bool
+		reason   string
+	}{
+		{name: "dentro do limite", amount: 500, currency: "EUR", allowed: true},
+		{name: "moeda em minúsculas", amount: 500, currency: "eur", allowed: true},
+		{name: "acima do limite por transação", amount: 1500, currency: "EUR", reason: "merchant_single_transaction_limit_exceeded"},
+		{name: "outra moeda abaixo do limite", amount: 10, currency: "USD", reason: "merchant_limits_currency_mismatch"},
+		{name: "outra moeda acima do limite", amount: 1500, currency: "USD", reason: "merchant_limits_currency_mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.ValidatePayment(context.Background(), &PaymentRequest{
+				TenantID:   "tenant-1",
+				MerchantID: "mer-1",
+				Amount:     tt.amount,
+				Currency:   tt.currency,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, result.Allowed)
+			assert.Equal(t, tt.reason, result.Reason)
+		})
+	}
+}
+
+func TestUploadKYCDocumentRejectsOversizedWithoutStoring(t *testing.T) {
+	service, repository, store := newTestMerchantService(t)
+
+	oversized := bytes.NewReader(make([]byte, MaxKYCDocumentSizeBytes+1))
+	_, err := service.UploadKYCDocument(context.Background(), "tenant-1", "mer-1", "tax_certificate", "cert.pdf", "application/pdf", oversized)
+	require.ErrorIs(t, err, ErrKYCDocumentTooLarge)
+	assert.Empty(t, store.objects)
+	assert.Empty(t, repository.documents["mer-1"])
+
+	doc, err := service.UploadKYCDocument(context.Background(), "tenant-1", "mer-1", "tax_certificate", "../cert.pdf", "application/pdf", strings.NewReader("conteudo"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("conteudo")), doc.SizeBytes)
+	assert.Equal(t, "cert.pdf", doc.FileName)
+	assert.Equal(t, []byte("conteudo"), store.objects[doc.StorageKey])
+	assert.Len(t, doc.SHA256, 64)
+}
+
+func TestRecordTransactionOutcomeConcurrentCounters(t *testing.T) {
+	service, repository, _ := newTestMerchantService(t)
+
+	const outcomes = 50
+	req := &PaymentRequest{TenantID: "tenant-1", MerchantID: "mer-1", Amount: 10, Currency: "EUR"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < outcomes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status := TransactionStatusApproved
+			if i%2 == 1 {
+				status = TransactionStatusDenied
+			}
+			service.RecordTransactionOutcome(context.Background(), req, &PaymentResponse{Status: status, TrustScore: 80})
+		}(i)
+	}
+	wg.Wait()
+
+	metrics := repository.riskMetrics["mer-1"]
+	assert.Equal(t, int64(outcomes), metrics.TotalTransactions)
+	assert.Equal(t, int64(outcomes/2), metrics.ApprovedCount)
+	assert.Equal(t, int64(outcomes/2), metrics.DeniedCount)
+	assert.InDelta(t, 250.0, metrics.TotalVolume, 0.001)
+	assert.InDelta(t, 80.0, metrics.AverageTrustScore, 0.001)
+}
@@ -0,0 +1,153 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// NetworkTokenClient define as chamadas aos serviços de tokenização das redes (VTS, MDES)
+type NetworkTokenClient interface {
+	// Provision pede à rede um token para o cartão; recusas da rede ou do emissor retornam ErrNetworkTokenDeclined
+	Provision(ctx context.Context, req *NetworkTokenProvisionRequest) (*NetworkTokenProvisionResult, error)
+
+	// RequestCryptogram obtém o token e o criptograma de uso único para uma autorização
+	RequestCryptogram(ctx context.Context, req *NetworkCryptogramRequest) (*NetworkCryptogram, error)
+
+	// DeleteToken elimina o token na rede a pedido do titular ou do tenant
+	DeleteToken(ctx context.Context, network, tokenReferenceID, reason string) error
+}
+
+// networkTokenErrorResponse representa uma recusa devolvida pelo serviço de tokenização
+type networkTokenErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// HTTPNetworkTokenClient chama os serviços de tokenização das redes por HTTP/JSON
+type HTTPNetworkTokenClient struct {
+	providers  map[string]NetworkTokenProviderConfig
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPNetworkTokenClient cria o cliente dos serviços de tokenização configurados
+func NewHTTPNetworkTokenClient(config NetworkTokenConfig) *HTTPNetworkTokenClient {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultNetworkTokenHTTPTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPNetworkTokenClient{
+		providers:  config.Providers,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Provision pede o provisionamento do token; o identificador do cartão torna o pedido repetível
+func (c *HTTPNetworkTokenClient) Provision(ctx context.Context, req *NetworkTokenProvisionRequest) (*NetworkTokenProvisionResult, error) {
+	provider, ok := c.providers[req.Network]
+	if !ok {
+		return nil, ErrNetworkTokenProviderNotFound
+	}
+	payload := *req
+	payload.TokenRequestorID = provider.TokenRequestorID
+
+	var result NetworkTokenProvisionResult
+	if err := c.sendJSON(ctx, provider, "/tokens", req.ReferenceID, &payload, &result); err != nil {
+		return nil, err
+	}
+	if result.TokenReferenceID == "" {
+		return nil, fmt.Errorf("%w: resposta sem referência do token", ErrNetworkTokenUnavailable)
+	}
+	return &result, nil
+}
+
+// RequestCryptogram pede o token e o criptograma; o identificador da transação torna o pedido repetível
+func (c *HTTPNetworkTokenClient) RequestCryptogram(ctx context.Context, req *NetworkCryptogramRequest) (*NetworkCryptogram, error) {
+	provider, ok := c.providers[req.Network]
+	if !ok {
+		return nil, ErrNetworkTokenProviderNotFound
+	}
+
+	var cryptogram NetworkCryptogram
+	endpoint := "/tokens/" + url.PathEscape(req.TokenReferenceID) + "/cryptograms"
+	if err := c.sendJSON(ctx, provider, endpoint, req.TransactionID, req, &cryptogram); err != nil {
+		return nil, err
+	}
+	if cryptogram.TokenValue == "" || cryptogram.Cryptogram == "" {
+		return nil, fmt.Errorf("%w: resposta sem token ou criptograma", ErrNetworkTokenUnavailable)
+	}
+	return &cryptogram, nil
+}
+
+// DeleteToken pede a eliminação do token na rede
+func (c *HTTPNetworkTokenClient) DeleteToken(ctx context.Context, network, tokenReferenceID, reason string) error {
+	provider, ok := c.providers[network]
+	if !ok {
+		return ErrNetworkTokenProviderNotFound
+	}
+
+	endpoint := "/tokens/" + url.PathEscape(tokenReferenceID) + "/delete"
+	return c.sendJSON(ctx, provider, endpoint, "delete-"+tokenReferenceID, map[string]string{"reason": reason}, nil)
+}
+
+// sendJSON envia um POST JSON ao serviço de tokenização da rede e decodifica a resposta
+// Respostas 4xx indicam recusa da rede ou do emissor; falhas de rede e respostas 5xx indicam indisponibilidade.
+// O corpo pode conter o PAN e nunca é incluído nos erros
+func (c *HTTPNetworkTokenClient) sendJSON(ctx context.Context, provider NetworkTokenProviderConfig, endpoint, idempotencyKey string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	target := strings.TrimRight(provider.APIURL, "/") + endpoint
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if idempotencyKey != "" {
+			req.Header.Set(resilience.IdempotencyKeyHeader, idempotencyKey)
+		}
+		if provider.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+provider.APIKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNetworkTokenUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNetworkTokenUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s retornou status %d", ErrNetworkTokenUnavailable, endpoint, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		var rejection networkTokenErrorResponse
+		if json.Unmarshal(body, &rejection) == nil && rejection.Code != "" {
+			return fmt.Errorf("%w: %s %s", ErrNetworkTokenDeclined, rejection.Code, rejection.Message)
+		}
+		return fmt.Errorf("%w: %s retornou status %d", ErrNetworkTokenDeclined, endpoint, resp.StatusCode)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
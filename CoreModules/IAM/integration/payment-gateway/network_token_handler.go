@@ -0,0 +1,136 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Tamanho máximo de uma notificação do ciclo de vida enviada pelas redes
+const maxNetworkTokenEventBytes = 64 << 10
+
+// NetworkTokenHandler expõe a API HTTP da tokenização de rede e recebe as notificações das redes
+type NetworkTokenHandler struct {
+	service *NetworkTokenService
+}
+
+// NewNetworkTokenHandler cria uma nova instância do NetworkTokenHandler
+func NewNetworkTokenHandler(service *NetworkTokenService) *NetworkTokenHandler {
+	return &NetworkTokenHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *NetworkTokenHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/network-tokens/events/{network}", h.HandleLifecycleEvent).Methods(http.MethodPost)
+	router.HandleFunc("/network-tokens", h.EnrollCard).Methods(http.MethodPost)
+	router.HandleFunc("/network-tokens", h.ListCardTokens).Methods(http.MethodGet)
+	router.HandleFunc("/network-tokens/{card_token_id}", h.GetCardToken).Methods(http.MethodGet)
+	router.HandleFunc("/network-tokens/{card_token_id}", h.DeleteNetworkToken).Methods(http.MethodDelete)
+	router.HandleFunc("/network-tokens/{card_token_id}/provision", h.RetryProvisioning).Methods(http.MethodPost)
+}
+
+// EnrollCard regista um cartão do cofre e provisiona o seu token de rede
+func (h *NetworkTokenHandler) EnrollCard(w http.ResponseWriter, r *http.Request) {
+	var req CardTokenEnrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	card, err := h.service.EnrollCard(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, card)
+}
+
+// ListCardTokens lista os cartões tokenizados do usuário indicado em user_id
+func (h *NetworkTokenHandler) ListCardTokens(w http.ResponseWriter, r *http.Request) {
+	cards, err := h.service.ListCardTokens(r.Context(), r.Header.Get("X-Tenant-ID"), r.URL.Query().Get("user_id"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cards)
+}
+
+// GetCardToken retorna o cartão tokenizado e o estado do seu token de rede
+func (h *NetworkTokenHandler) GetCardToken(w http.ResponseWriter, r *http.Request) {
+	card, err := h.service.GetCardToken(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["card_token_id"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+// DeleteNetworkToken elimina o token de rede do cartão; o motivo é indicado em reason
+func (h *NetworkTokenHandler) DeleteNetworkToken(w http.ResponseWriter, r *http.Request) {
+	card, err := h.service.DeleteNetworkToken(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["card_token_id"], r.URL.Query().Get("reason"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+// RetryProvisioning repete o provisionamento de um token pendente
+func (h *NetworkTokenHandler) RetryProvisioning(w http.ResponseWriter, r *http.Request) {
+	card, err := h.service.RetryProvisioning(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["card_token_id"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, card)
+}
+
+// HandleLifecycleEvent recebe uma suspensão, retoma, eliminação ou atualização de token enviada pela rede
+// O corpo é verificado com a assinatura HMAC antes de ser interpretado
+func (h *NetworkTokenHandler) HandleLifecycleEvent(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxNetworkTokenEventBytes))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	event, err := h.service.HandleLifecycleEvent(r.Context(), mux.Vars(r)["network"], payload,
+		r.Header.Get(NetworkTokenTimestampHeader), r.Header.Get(NetworkTokenSignatureHeader))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, event)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *NetworkTokenHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCardTokenInvalid), errors.Is(err, ErrNetworkTokenEventInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrNetworkTokenSignatureInvalid):
+		respondWithError(w, http.StatusUnauthorized, "invalid_signature", err.Error())
+	case errors.Is(err, ErrCardTokenNotFound):
+		respondWithError(w, http.StatusNotFound, "card_token_not_found", err.Error())
+	case errors.Is(err, ErrVaultCardNotFound):
+		respondWithError(w, http.StatusNotFound, "vault_card_not_found", err.Error())
+	case errors.Is(err, ErrNetworkTokenProviderNotFound):
+		respondWithError(w, http.StatusNotFound, "network_not_configured", err.Error())
+	case errors.Is(err, ErrNetworkTokenStateInvalid):
+		respondWithError(w, http.StatusConflict, "invalid_token_state", err.Error())
+	case errors.Is(err, ErrNetworkTokenUnavailable):
+		respondWithError(w, http.StatusServiceUnavailable, "network_unavailable", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "network_token_error", err.Error())
+	}
+}
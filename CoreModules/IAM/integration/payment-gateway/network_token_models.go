@@ -0,0 +1,233 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Redes de cartão com tokenização de rede
+const (
+	CardNetworkVisa       = "visa"       // Visa Token Service (VTS)
+	CardNetworkMastercard = "mastercard" // Mastercard Digital Enablement Service (MDES)
+)
+
+// Estados do token de rede de um cartão
+const (
+	NetworkTokenStatusPending    = "pending"    // Provisionamento por concluir; nova tentativa possível
+	NetworkTokenStatusActive     = "active"     // Token utilizável nas autorizações
+	NetworkTokenStatusSuspended  = "suspended"  // Suspenso pelo emissor; pode ser retomado
+	NetworkTokenStatusDeleted    = "deleted"    // Eliminado pelo emissor ou a pedido do titular
+	NetworkTokenStatusIneligible = "ineligible" // Cartão sem tokenização de rede (rede ou emissor recusou)
+)
+
+// Tipos de credencial usados na autorização de um pagamento com cartão
+const (
+	CardCredentialNetworkToken = "network_token" // Token de rede com criptograma
+	CardCredentialVaultToken   = "vault_token"   // PAN do cofre (FPAN), usado na falta do token de rede
+)
+
+// Eventos do ciclo de vida do token enviados pelas redes em nome dos emissores
+const (
+	NetworkTokenEventSuspend = "suspend"
+	NetworkTokenEventResume  = "resume"
+	NetworkTokenEventDelete  = "delete"
+	NetworkTokenEventUpdate  = "update" // Novo prazo de validade ou novo cartão subjacente
+)
+
+// Motivos do uso do PAN do cofre em vez do token de rede
+const (
+	NetworkTokenFallbackNoToken           = "no_network_token"
+	NetworkTokenFallbackTokenSuspended    = "network_token_suspended"
+	NetworkTokenFallbackProviderDisabled  = "provider_disabled"
+	NetworkTokenFallbackCryptogramFailure = "cryptogram_unavailable"
+)
+
+// Cabeçalhos dos eventos do ciclo de vida enviados pelas redes
+const (
+	NetworkTokenSignatureHeader = "X-Network-Token-Signature"
+	NetworkTokenTimestampHeader = "X-Network-Token-Timestamp"
+)
+
+// Valores padrão da tokenização de rede
+const (
+	DefaultNetworkTokenHTTPTimeout  = 10 * time.Second
+	DefaultNetworkTokenEventMaxSkew = 5 * time.Minute
+)
+
+// Erros da tokenização de rede
+var (
+	ErrCardTokenNotFound            = errors.New("cartão tokenizado não encontrado")
+	ErrCardTokenInvalid             = errors.New("pedido de tokenização inválido")
+	ErrCardCredentialUnavailable    = errors.New("credencial do cartão indisponível")
+	ErrNetworkTokenProviderNotFound = errors.New("rede de cartão sem tokenização configurada")
+	ErrNetworkTokenDeclined         = errors.New("tokenização recusada pela rede")
+	ErrNetworkTokenUnavailable      = errors.New("serviço de tokenização da rede indisponível")
+	ErrNetworkTokenEventInvalid     = errors.New("evento do ciclo de vida do token inválido")
+	ErrNetworkTokenSignatureInvalid = errors.New("assinatura do evento do token inválida")
+	ErrNetworkTokenStateInvalid     = errors.New("estado do token de rede não permite a operação")
+	ErrVaultCardNotFound            = errors.New("cartão não encontrado no cofre")
+)
+
+// CardToken associa um cartão guardado no cofre (token do cofre) ao seu token de rede
+// O PAN, o token de rede e os criptogramas nunca são persistidos; o token e o criptograma
+// são obtidos junto da rede em cada autorização
+type CardToken struct {
+	CardTokenID             string     `json:"card_token_id" db:"card_token_id"`
+	TenantID                string     `json:"tenant_id" db:"tenant_id"`
+	UserID                  string     `json:"user_id" db:"user_id"`
+	VaultTokenID            string     `json:"vault_token_id" db:"vault_token_id"`
+	Network                 string     `json:"network" db:"network"`
+	PANLast4                string     `json:"pan_last4" db:"pan_last4"`
+	Status                  string     `json:"status" db:"status"`
+	StatusReason            string     `json:"status_reason,omitempty" db:"status_reason"`
+	TokenReferenceID        string     `json:"token_reference_id,omitempty" db:"token_reference_id"`
+	TokenLast4              string     `json:"token_last4,omitempty" db:"token_last4"`
+	TokenExpiryMonth        int        `json:"token_expiry_month,omitempty" db:"token_expiry_month"`
+	TokenExpiryYear         int        `json:"token_expiry_year,omitempty" db:"token_expiry_year"`
+	PaymentAccountReference string     `json:"payment_account_reference,omitempty" db:"payment_account_reference"`
+	ProvisioningAttempts    int        `json:"provisioning_attempts" db:"provisioning_attempts"`
+	ProvisionedAt           *time.Time `json:"provisioned_at,omitempty" db:"provisioned_at"`
+	LastEventAt             *time.Time `json:"last_event_at,omitempty" db:"last_event_at"`
+	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CardTokenEnrollRequest representa o registo de um cartão do cofre para tokenização de rede
+type CardTokenEnrollRequest struct {
+	TenantID     string `json:"-"`
+	UserID       string `json:"user_id"`
+	VaultTokenID string `json:"vault_token_id"`
+}
+
+// VaultCard contém os dados do cartão devolvidos pelo cofre, usados apenas no provisionamento
+type VaultCard struct {
+	PAN            string `json:"-"`
+	ExpiryMonth    int    `json:"-"`
+	ExpiryYear     int    `json:"-"`
+	CardholderName string `json:"-"`
+}
+
+// NetworkTokenProvisionRequest é o pedido de provisionamento enviado à rede
+type NetworkTokenProvisionRequest struct {
+	Network          string `json:"-"`
+	TokenRequestorID string `json:"token_requestor_id"`
+	PAN              string `json:"pan"`
+	ExpiryMonth      int    `json:"expiry_month"`
+	ExpiryYear       int    `json:"expiry_year"`
+	CardholderName   string `json:"cardholder_name,omitempty"`
+	ReferenceID      string `json:"reference_id"` // Identificador do cartão tokenizado no gateway
+}
+
+// NetworkTokenProvisionResult é a resposta da rede ao provisionamento
+type NetworkTokenProvisionResult struct {
+	TokenReferenceID        string `json:"token_reference_id"`
+	Status                  string `json:"status"`
+	TokenLast4              string `json:"token_last4"`
+	TokenExpiryMonth        int    `json:"token_expiry_month"`
+	TokenExpiryYear         int    `json:"token_expiry_year"`
+	PaymentAccountReference string `json:"payment_account_reference,omitempty"`
+}
+
+// NetworkCryptogramRequest é o pedido do token e do criptograma para uma autorização
+type NetworkCryptogramRequest struct {
+	Network          string  `json:"-"`
+	TokenReferenceID string  `json:"-"`
+	TransactionID    string  `json:"transaction_id"`
+	Amount           float64 `json:"amount"`
+	Currency         string  `json:"currency"`
+	MerchantID       string  `json:"merchant_id"`
+}
+
+// NetworkCryptogram contém o token de rede e o criptograma de uso único de uma autorização
+type NetworkCryptogram struct {
+	TokenValue  string `json:"token"`
+	ExpiryMonth int    `json:"expiry_month"`
+	ExpiryYear  int    `json:"expiry_year"`
+	Cryptogram  string `json:"cryptogram"`
+	ECI         string `json:"eci,omitempty"`
+}
+
+// CardCredential é a credencial do cartão a usar na autorização do pagamento
+// Com token de rede, leva o token e o criptograma; na falta dele, o token do cofre
+type CardCredential struct {
+	Type             string `json:"type"`
+	CardTokenID      string `json:"card_token_id"`
+	Network          string `json:"network,omitempty"`
+	TokenReferenceID string `json:"token_reference_id,omitempty"`
+	NetworkToken     string `json:"network_token,omitempty"`
+	TokenExpiryMonth int    `json:"token_expiry_month,omitempty"`
+	TokenExpiryYear  int    `json:"token_expiry_year,omitempty"`
+	Cryptogram       string `json:"cryptogram,omitempty"`
+	ECI              string `json:"eci,omitempty"`
+	VaultTokenID     string `json:"vault_token_id,omitempty"`
+	FallbackReason   string `json:"fallback_reason,omitempty"`
+}
+
+// NetworkTokenLifecycleEvent é uma alteração do token decidida pelo emissor e notificada pela rede
+type NetworkTokenLifecycleEvent struct {
+	EventID          string    `json:"event_id" db:"event_id"`
+	Network          string    `json:"network" db:"network"`
+	TokenReferenceID string    `json:"token_reference_id" db:"token_reference_id"`
+	EventType        string    `json:"event_type" db:"event_type"`
+	Reason           string    `json:"reason,omitempty" db:"reason"`
+	TokenLast4       string    `json:"token_last4,omitempty" db:"-"`
+	TokenExpiryMonth int       `json:"token_expiry_month,omitempty" db:"-"`
+	TokenExpiryYear  int       `json:"token_expiry_year,omitempty" db:"-"`
+	OccurredAt       time.Time `json:"occurred_at" db:"occurred_at"`
+	ReceivedAt       time.Time `json:"received_at" db:"received_at"`
+	Applied          bool      `json:"applied" db:"applied"` // Falso para eventos repetidos ou anteriores ao estado atual
+}
+
+// Validate verifica a identificação e o tipo do evento
+func (e *NetworkTokenLifecycleEvent) Validate() error {
+	if e.EventID == "" || e.TokenReferenceID == "" || e.OccurredAt.IsZero() {
+		return ErrNetworkTokenEventInvalid
+	}
+	switch e.EventType {
+	case NetworkTokenEventSuspend, NetworkTokenEventResume, NetworkTokenEventDelete, NetworkTokenEventUpdate:
+		return nil
+	}
+	return ErrNetworkTokenEventInvalid
+}
+
+// NetworkTokenProviderConfig define o acesso ao serviço de tokenização de uma rede
+type NetworkTokenProviderConfig struct {
+	APIURL           string `json:"api_url"`
+	APIKey           string `json:"-"`
+	TokenRequestorID string `json:"token_requestor_id"`
+
+	// Segredo HMAC-SHA256 das notificações do ciclo de vida enviadas pela rede
+	WebhookSecret string `json:"-"`
+}
+
+// NetworkTokenConfig contém as configurações da tokenização de rede
+type NetworkTokenConfig struct {
+	// Serviços de tokenização por rede (visa, mastercard); redes ausentes usam sempre o cofre
+	Providers map[string]NetworkTokenProviderConfig `json:"providers"`
+
+	HTTPTimeout time.Duration `json:"http_timeout"`
+
+	// Resilience sobrepõe HTTPTimeout e define as novas tentativas das chamadas às redes
+	Resilience resilience.Policy `json:"resilience"`
+
+	// Desvio máximo entre o carimbo temporal assinado de uma notificação e o relógio local
+	EventMaxSkew time.Duration `json:"event_max_skew"`
+}
+
+// cardNetworkFromPAN identifica a rede do cartão pelo BIN, ou "" se não for Visa nem Mastercard
+func cardNetworkFromPAN(pan string) string {
+	if len(pan) < 13 || !isDigits(pan) {
+		return ""
+	}
+	if pan[0] == '4' {
+		return CardNetworkVisa
+	}
+	prefix2 := int(pan[0]-'0')*10 + int(pan[1]-'0')
+	prefix4 := prefix2*100 + int(pan[2]-'0')*10 + int(pan[3]-'0')
+	if (prefix2 >= 51 && prefix2 <= 55) || (prefix4 >= 2221 && prefix4 <= 2720) {
+		return CardNetworkMastercard
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresNetworkTokenStore implementa NetworkTokenStore para PostgreSQL
+type PostgresNetworkTokenStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresNetworkTokenStore cria uma nova instância de PostgresNetworkTokenStore
+func NewPostgresNetworkTokenStore(db *sqlx.DB) *PostgresNetworkTokenStore {
+	return &PostgresNetworkTokenStore{db: db}
+}
+
+// cardTokenColumns são as colunas lidas de payment_gateway.card_tokens
+const cardTokenColumns = `
+	card_token_id, tenant_id, user_id, vault_token_id, network, pan_last4, status, status_reason,
+	token_reference_id, token_last4, token_expiry_month, token_expiry_year, payment_account_reference,
+	provisioning_attempts, provisioned_at, last_event_at, created_at, updated_at
+`
+
+// SaveCardToken grava o cartão tokenizado; um novo registo substitui o anterior
+func (r *PostgresNetworkTokenStore) SaveCardToken(ctx context.Context, card *CardToken) error {
+	query := `
+		INSERT INTO payment_gateway.card_tokens (
+			card_token_id, tenant_id, user_id, vault_token_id, network, pan_last4, status, status_reason,
+			token_reference_id, token_last4, token_expiry_month, token_expiry_year, payment_account_reference,
+			provisioning_attempts, provisioned_at, last_event_at, created_at, updated_at
+		) VALUES (
+			:card_token_id, :tenant_id, :user_id, :vault_token_id, :network, :pan_last4, :status, :status_reason,
+			:token_reference_id, :token_last4, :token_expiry_month, :token_expiry_year, :payment_account_reference,
+			:provisioning_attempts, :provisioned_at, :last_event_at, :created_at, :updated_at
+		)
+		ON CONFLICT (card_token_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			status_reason = EXCLUDED.status_reason,
+			token_reference_id = EXCLUDED.token_reference_id,
+			token_last4 = EXCLUDED.token_last4,
+			token_expiry_month = EXCLUDED.token_expiry_month,
+			token_expiry_year = EXCLUDED.token_expiry_year,
+			payment_account_reference = EXCLUDED.payment_account_reference,
+			provisioning_attempts = EXCLUDED.provisioning_attempts,
+			provisioned_at = EXCLUDED.provisioned_at,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, card); err != nil {
+		return fmt.Errorf("falha ao gravar cartão tokenizado: %w", err)
+	}
+
+	return nil
+}
+
+// GetCardToken recupera um cartão tokenizado do tenant, ou nil se não existir
+func (r *PostgresNetworkTokenStore) GetCardToken(ctx context.Context, tenantID, cardTokenID string) (*CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM payment_gateway.card_tokens WHERE tenant_id = $1 AND card_token_id = $2`
+	return r.getCardToken(ctx, query, tenantID, cardTokenID)
+}
+
+// GetCardTokenByVaultToken recupera o cartão tokenizado a partir do token do cofre, ou nil se não existir
+func (r *PostgresNetworkTokenStore) GetCardTokenByVaultToken(ctx context.Context, tenantID, vaultTokenID string) (*CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM payment_gateway.card_tokens WHERE tenant_id = $1 AND vault_token_id = $2`
+	return r.getCardToken(ctx, query, tenantID, vaultTokenID)
+}
+
+// GetCardTokenByReference recupera o cartão a partir da referência do token na rede, ou nil se não existir
+func (r *PostgresNetworkTokenStore) GetCardTokenByReference(ctx context.Context, network, tokenReferenceID string) (*CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM payment_gateway.card_tokens WHERE network = $1 AND token_reference_id = $2`
+	return r.getCardToken(ctx, query, network, tokenReferenceID)
+}
+
+// ListCardTokens lista os cartões tokenizados de um usuário do tenant, do mais recente para o mais antigo
+func (r *PostgresNetworkTokenStore) ListCardTokens(ctx context.Context, tenantID, userID string) ([]*CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM payment_gateway.card_tokens
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY created_at DESC`
+
+	cards := make([]*CardToken, 0)
+	if err := r.db.SelectContext(ctx, &cards, query, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("falha ao listar cartões tokenizados: %w", err)
+	}
+
+	return cards, nil
+}
+
+// RecordLifecycleEvent grava o evento e retorna false se um evento com o mesmo identificador já existia
+func (r *PostgresNetworkTokenStore) RecordLifecycleEvent(ctx context.Context, event *NetworkTokenLifecycleEvent) (bool, error) {
+	query := `
+		INSERT INTO payment_gateway.network_token_events (
+			network, event_id, token_reference_id, event_type, reason, occurred_at, received_at, applied
+		) VALUES (
+			:network, :event_id, :token_reference_id, :event_type, :reason, :occurred_at, :received_at, :applied
+		)
+		ON CONFLICT (network, event_id) DO NOTHING
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, event)
+	if err != nil {
+		return false, fmt.Errorf("falha ao gravar evento do token de rede: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("falha ao gravar evento do token de rede: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// getCardToken executa a consulta de um único cartão tokenizado
+func (r *PostgresNetworkTokenStore) getCardToken(ctx context.Context, query string, args ...interface{}) (*CardToken, error) {
+	var card CardToken
+	if err := r.db.GetContext(ctx, &card, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar cartão tokenizado: %w", err)
+	}
+
+	return &card, nil
+}
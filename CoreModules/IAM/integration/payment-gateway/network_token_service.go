@@ -0,0 +1,486 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// CardVault dá acesso aos cartões guardados no cofre de PAN (FPAN) do gateway
+type CardVault interface {
+	// GetCard retorna os dados do cartão do token do cofre, ou ErrVaultCardNotFound se não existir
+	GetCard(ctx context.Context, tenantID, vaultTokenID string) (*VaultCard, error)
+}
+
+// NetworkTokenService provisiona tokens de rede Visa/Mastercard para os cartões do cofre, obtém
+// o token e o criptograma de cada autorização e aplica as suspensões, retomas e eliminações
+// decididas pelos emissores. Sem token de rede utilizável, a autorização usa o token do cofre
+type NetworkTokenService struct {
+	config NetworkTokenConfig
+	store  NetworkTokenStore
+	vault  CardVault
+	client NetworkTokenClient
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewNetworkTokenService cria o serviço de tokenização de rede
+// Sem cliente, as redes são chamadas por HTTP segundo os serviços configurados
+func NewNetworkTokenService(config NetworkTokenConfig, store NetworkTokenStore, vault CardVault, client NetworkTokenClient) (*NetworkTokenService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-network-tokens",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	for network, provider := range config.Providers {
+		if network != CardNetworkVisa && network != CardNetworkMastercard {
+			return nil, fmt.Errorf("rede de cartão sem tokenização suportada: %q", network)
+		}
+		if client == nil && provider.APIURL == "" {
+			return nil, fmt.Errorf("URL do serviço de tokenização não configurada para %s", network)
+		}
+	}
+	if config.EventMaxSkew <= 0 {
+		config.EventMaxSkew = DefaultNetworkTokenEventMaxSkew
+	}
+	if client == nil {
+		client = NewHTTPNetworkTokenClient(config)
+	}
+
+	return &NetworkTokenService{
+		config:          config,
+		store:           store,
+		vault:           vault,
+		client:          client,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}, nil
+}
+
+// SetClock substitui o relógio usado no registo dos cartões e na verificação das notificações
+func (s *NetworkTokenService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// EnrollCard associa um cartão do cofre à tokenização de rede e provisiona o token
+// O cartão fica registado mesmo quando a rede recusa ou está indisponível, para que as
+// autorizações usem o token do cofre; um cartão já registado é devolvido sem novo pedido
+func (s *NetworkTokenService) EnrollCard(ctx context.Context, req *CardTokenEnrollRequest) (*CardToken, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "NetworkTokenService.EnrollCard")
+	defer span.End()
+
+	if req.TenantID == "" || req.UserID == "" || req.VaultTokenID == "" {
+		return nil, ErrCardTokenInvalid
+	}
+
+	card, err := s.store.GetCardTokenByVaultToken(ctx, req.TenantID, req.VaultTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if card != nil && card.Status != NetworkTokenStatusDeleted {
+		if card.UserID != req.UserID {
+			return nil, fmt.Errorf("%w: token do cofre associado a outro usuário", ErrCardTokenInvalid)
+		}
+		return card, nil
+	}
+
+	vaultCard, err := s.vault.GetCard(ctx, req.TenantID, req.VaultTokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if card == nil {
+		card = &CardToken{
+			CardTokenID:  fmt.Sprintf("ctk-%s", uuid.New().String()),
+			TenantID:     req.TenantID,
+			UserID:       req.UserID,
+			VaultTokenID: req.VaultTokenID,
+			CreatedAt:    now,
+		}
+	} else {
+		// Um cartão cujo token foi eliminado volta a ser provisionado com um novo token
+		card.UserID = req.UserID
+		card.TokenReferenceID, card.TokenLast4, card.PaymentAccountReference = "", "", ""
+		card.TokenExpiryMonth, card.TokenExpiryYear = 0, 0
+		card.ProvisionedAt, card.LastEventAt = nil, nil
+	}
+	card.Network = cardNetworkFromPAN(vaultCard.PAN)
+	card.PANLast4 = lastDigits(vaultCard.PAN, 4)
+	card.UpdatedAt = now
+
+	if card.Network == "" {
+		card.Status = NetworkTokenStatusIneligible
+		card.StatusReason = "rede do cartão sem tokenização de rede"
+	} else {
+		s.provision(ctx, card, vaultCard)
+	}
+
+	if err := s.store.SaveCardToken(ctx, card); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Cartão registado para tokenização de rede",
+		"card_token_id", card.CardTokenID,
+		"network", card.Network,
+		"status", card.Status)
+
+	return card, nil
+}
+
+// RetryProvisioning repete o provisionamento de um cartão cujo token ficou pendente
+func (s *NetworkTokenService) RetryProvisioning(ctx context.Context, tenantID, cardTokenID string) (*CardToken, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "NetworkTokenService.RetryProvisioning")
+	defer span.End()
+
+	card, err := s.GetCardToken(ctx, tenantID, cardTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != NetworkTokenStatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrNetworkTokenStateInvalid, card.Status)
+	}
+
+	vaultCard, err := s.vault.GetCard(ctx, tenantID, card.VaultTokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.provision(ctx, card, vaultCard)
+	card.UpdatedAt = s.now()
+	if err := s.store.SaveCardToken(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// GetCardToken retorna um cartão tokenizado do tenant
+func (s *NetworkTokenService) GetCardToken(ctx context.Context, tenantID, cardTokenID string) (*CardToken, error) {
+	card, err := s.store.GetCardToken(ctx, tenantID, cardTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, ErrCardTokenNotFound
+	}
+	return card, nil
+}
+
+// ListCardTokens lista os cartões tokenizados de um usuário do tenant
+func (s *NetworkTokenService) ListCardTokens(ctx context.Context, tenantID, userID string) ([]*CardToken, error) {
+	if userID == "" {
+		return nil, ErrCardTokenInvalid
+	}
+	return s.store.ListCardTokens(ctx, tenantID, userID)
+}
+
+// DeleteNetworkToken elimina o token de rede a pedido do titular ou do tenant
+// O cartão continua no cofre e as autorizações seguintes usam o token do cofre
+func (s *NetworkTokenService) DeleteNetworkToken(ctx context.Context, tenantID, cardTokenID, reason string) (*CardToken, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "NetworkTokenService.DeleteNetworkToken")
+	defer span.End()
+
+	card, err := s.GetCardToken(ctx, tenantID, cardTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status == NetworkTokenStatusDeleted {
+		return card, nil
+	}
+
+	if card.TokenReferenceID != "" {
+		if err := s.client.DeleteToken(ctx, card.Network, card.TokenReferenceID, reason); err != nil && !errors.Is(err, ErrNetworkTokenDeclined) {
+			return nil, err
+		}
+	}
+
+	card.Status = NetworkTokenStatusDeleted
+	card.StatusReason = reason
+	card.UpdatedAt = s.now()
+	if err := s.store.SaveCardToken(ctx, card); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Token de rede eliminado",
+		"card_token_id", card.CardTokenID,
+		"network", card.Network)
+
+	return card, nil
+}
+
+// AuthorizationCredential retorna a credencial do cartão do pagamento para a autorização
+// Com token de rede ativo, pede à rede o token e o criptograma da transação; se o token não
+// estiver ativo ou a rede não responder, retorna o token do cofre com o motivo da alternativa
+func (s *NetworkTokenService) AuthorizationCredential(ctx context.Context, req *PaymentRequest) (*CardCredential, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "NetworkTokenService.AuthorizationCredential")
+	defer span.End()
+
+	card, err := s.store.GetCardToken(ctx, req.TenantID, req.CardTokenID)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil || card.UserID != req.UserID {
+		return nil, ErrCardTokenNotFound
+	}
+
+	credential := &CardCredential{
+		CardTokenID: card.CardTokenID,
+		Network:     card.Network,
+	}
+
+	switch {
+	case card.Status == NetworkTokenStatusSuspended:
+		credential.FallbackReason = NetworkTokenFallbackTokenSuspended
+	case card.Status != NetworkTokenStatusActive:
+		credential.FallbackReason = NetworkTokenFallbackNoToken
+	case !s.providerEnabled(card.Network):
+		credential.FallbackReason = NetworkTokenFallbackProviderDisabled
+	default:
+		cryptogram, err := s.client.RequestCryptogram(ctx, &NetworkCryptogramRequest{
+			Network:          card.Network,
+			TokenReferenceID: card.TokenReferenceID,
+			TransactionID:    req.TransactionID,
+			Amount:           req.Amount,
+			Currency:         req.Currency,
+			MerchantID:       req.MerchantID,
+		})
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "Criptograma do token de rede indisponível; usando token do cofre",
+				"transaction_id", req.TransactionID,
+				"card_token_id", card.CardTokenID,
+				"network", card.Network,
+				"error", err.Error())
+			credential.FallbackReason = NetworkTokenFallbackCryptogramFailure
+			break
+		}
+
+		credential.Type = CardCredentialNetworkToken
+		credential.TokenReferenceID = card.TokenReferenceID
+		credential.NetworkToken = cryptogram.TokenValue
+		credential.TokenExpiryMonth = cryptogram.ExpiryMonth
+		credential.TokenExpiryYear = cryptogram.ExpiryYear
+		credential.Cryptogram = cryptogram.Cryptogram
+		credential.ECI = cryptogram.ECI
+	}
+
+	if credential.Type == "" {
+		if card.VaultTokenID == "" {
+			return nil, ErrCardCredentialUnavailable
+		}
+		credential.Type = CardCredentialVaultToken
+		credential.VaultTokenID = card.VaultTokenID
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_card_credentials_total", map[string]string{
+		"network":         card.Network,
+		"type":            credential.Type,
+		"fallback_reason": credential.FallbackReason,
+	})
+
+	return credential, nil
+}
+
+// HandleLifecycleEvent verifica a assinatura de uma notificação da rede e aplica o evento ao token
+// Eventos repetidos, anteriores ao último evento aplicado ou de tokens desconhecidos são registados
+// sem alterar o estado, para que a rede não volte a enviá-los
+func (s *NetworkTokenService) HandleLifecycleEvent(ctx context.Context, network string, payload []byte, timestamp, signature string) (*NetworkTokenLifecycleEvent, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "NetworkTokenService.HandleLifecycleEvent")
+	defer span.End()
+
+	provider, ok := s.config.Providers[network]
+	if !ok {
+		return nil, ErrNetworkTokenProviderNotFound
+	}
+	if err := s.verifyEventSignature(provider, payload, timestamp, signature); err != nil {
+		return nil, err
+	}
+
+	var event NetworkTokenLifecycleEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, ErrNetworkTokenEventInvalid
+	}
+	event.Network = network
+	event.ReceivedAt = s.now()
+	event.Applied = false
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	card, err := s.store.GetCardTokenByReference(ctx, network, event.TokenReferenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// O cartão é gravado antes do evento: uma notificação repetida depois de uma falha volta a
+	// ser aplicada, e os eventos aplicados mais do que uma vez produzem o mesmo estado
+	if card != nil && s.applyLifecycleEvent(card, &event) {
+		if err := s.store.SaveCardToken(ctx, card); err != nil {
+			return nil, err
+		}
+		event.Applied = true
+	}
+
+	if _, err := s.store.RecordLifecycleEvent(ctx, &event); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_network_token_events_total", map[string]string{
+		"network":    network,
+		"event_type": event.EventType,
+		"applied":    strconv.FormatBool(event.Applied),
+	})
+
+	s.logger.InfoWithContext(ctx, "Evento do ciclo de vida do token de rede recebido",
+		"event_id", event.EventID,
+		"network", network,
+		"event_type", event.EventType,
+		"applied", event.Applied)
+
+	return &event, nil
+}
+
+// provision pede o token à rede e atualiza o estado do cartão com o resultado
+func (s *NetworkTokenService) provision(ctx context.Context, card *CardToken, vaultCard *VaultCard) {
+	if !s.providerEnabled(card.Network) {
+		card.Status = NetworkTokenStatusPending
+		card.StatusReason = ErrNetworkTokenProviderNotFound.Error()
+		return
+	}
+
+	card.ProvisioningAttempts++
+	result, err := s.client.Provision(ctx, &NetworkTokenProvisionRequest{
+		Network:        card.Network,
+		PAN:            vaultCard.PAN,
+		ExpiryMonth:    vaultCard.ExpiryMonth,
+		ExpiryYear:     vaultCard.ExpiryYear,
+		CardholderName: vaultCard.CardholderName,
+		ReferenceID:    card.CardTokenID,
+	})
+
+	outcome := "provisioned"
+	switch {
+	case errors.Is(err, ErrNetworkTokenDeclined):
+		outcome = "declined"
+		card.Status = NetworkTokenStatusIneligible
+		card.StatusReason = err.Error()
+	case err != nil:
+		outcome = "unavailable"
+		card.Status = NetworkTokenStatusPending
+		card.StatusReason = err.Error()
+	default:
+		provisionedAt := s.now()
+		card.Status = NetworkTokenStatusActive
+		if result.Status == NetworkTokenStatusSuspended {
+			card.Status = NetworkTokenStatusSuspended
+		}
+		card.StatusReason = ""
+		card.TokenReferenceID = result.TokenReferenceID
+		card.TokenLast4 = result.TokenLast4
+		card.TokenExpiryMonth = result.TokenExpiryMonth
+		card.TokenExpiryYear = result.TokenExpiryYear
+		card.PaymentAccountReference = result.PaymentAccountReference
+		card.ProvisionedAt = &provisionedAt
+	}
+
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Falha no provisionamento do token de rede",
+			"card_token_id", card.CardTokenID,
+			"network", card.Network,
+			"error", err.Error())
+	}
+	s.metricsRecorder.CounterInc("payment_gateway_network_token_provisioning_total", map[string]string{
+		"network": card.Network,
+		"outcome": outcome,
+	})
+}
+
+// applyLifecycleEvent altera o cartão segundo o evento e indica se o evento foi aplicado
+// Um token eliminado não volta a ser alterado e os eventos fora de ordem são ignorados
+func (s *NetworkTokenService) applyLifecycleEvent(card *CardToken, event *NetworkTokenLifecycleEvent) bool {
+	if card.Status == NetworkTokenStatusDeleted {
+		return false
+	}
+	if card.LastEventAt != nil && event.OccurredAt.Before(*card.LastEventAt) {
+		return false
+	}
+
+	switch event.EventType {
+	case NetworkTokenEventSuspend:
+		if card.Status != NetworkTokenStatusActive && card.Status != NetworkTokenStatusSuspended {
+			return false
+		}
+		card.Status = NetworkTokenStatusSuspended
+	case NetworkTokenEventResume:
+		if card.Status != NetworkTokenStatusActive && card.Status != NetworkTokenStatusSuspended {
+			return false
+		}
+		card.Status = NetworkTokenStatusActive
+	case NetworkTokenEventDelete:
+		card.Status = NetworkTokenStatusDeleted
+	case NetworkTokenEventUpdate:
+		if event.TokenLast4 != "" {
+			card.TokenLast4 = event.TokenLast4
+		}
+		if event.TokenExpiryMonth > 0 && event.TokenExpiryYear > 0 {
+			card.TokenExpiryMonth = event.TokenExpiryMonth
+			card.TokenExpiryYear = event.TokenExpiryYear
+		}
+	}
+
+	occurredAt := event.OccurredAt
+	card.StatusReason = event.Reason
+	card.LastEventAt = &occurredAt
+	card.UpdatedAt = s.now()
+	return true
+}
+
+// verifyEventSignature valida a assinatura HMAC-SHA256 de "<timestamp>.<payload>" e o desvio do carimbo temporal
+func (s *NetworkTokenService) verifyEventSignature(provider NetworkTokenProviderConfig, payload []byte, timestamp, signature string) error {
+	if provider.WebhookSecret == "" || signature == "" {
+		return ErrNetworkTokenSignatureInvalid
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrNetworkTokenSignatureInvalid
+	}
+	skew := s.now().Sub(time.Unix(seconds, 0))
+	if skew > s.config.EventMaxSkew || skew < -s.config.EventMaxSkew {
+		return ErrNetworkTokenSignatureInvalid
+	}
+	expected := signCallback(provider.WebhookSecret, timestamp, payload)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrNetworkTokenSignatureInvalid
+	}
+	return nil
+}
+
+// providerEnabled indica se a rede tem o serviço de tokenização configurado
+func (s *NetworkTokenService) providerEnabled(network string) bool {
+	_, ok := s.config.Providers[network]
+	return ok
+}
+
+// lastDigits retorna os últimos n dígitos do número do cartão
+func lastDigits(pan string, n int) string {
+	if len(pan) <= n {
+		return pan
+	}
+	return pan[len(pan)-n:]
+}
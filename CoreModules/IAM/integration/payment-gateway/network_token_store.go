@@ -0,0 +1,126 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// NetworkTokenStore define a persistência dos cartões tokenizados e dos eventos do ciclo de vida
+type NetworkTokenStore interface {
+	// SaveCardToken grava o cartão tokenizado, substituindo um registo anterior com o mesmo identificador
+	SaveCardToken(ctx context.Context, card *CardToken) error
+
+	// GetCardToken recupera um cartão tokenizado do tenant, ou nil se não existir
+	GetCardToken(ctx context.Context, tenantID, cardTokenID string) (*CardToken, error)
+
+	// GetCardTokenByVaultToken recupera o cartão tokenizado a partir do token do cofre, ou nil se não existir
+	GetCardTokenByVaultToken(ctx context.Context, tenantID, vaultTokenID string) (*CardToken, error)
+
+	// GetCardTokenByReference recupera o cartão a partir da referência do token na rede, ou nil se não existir
+	GetCardTokenByReference(ctx context.Context, network, tokenReferenceID string) (*CardToken, error)
+
+	// ListCardTokens lista os cartões tokenizados de um usuário do tenant, do mais recente para o mais antigo
+	ListCardTokens(ctx context.Context, tenantID, userID string) ([]*CardToken, error)
+
+	// RecordLifecycleEvent grava o evento e retorna false se um evento com o mesmo identificador já existia
+	RecordLifecycleEvent(ctx context.Context, event *NetworkTokenLifecycleEvent) (bool, error)
+}
+
+// InMemoryNetworkTokenStore armazena os cartões tokenizados em memória
+type InMemoryNetworkTokenStore struct {
+	cards  map[string]*CardToken                  // Por identificador do cartão tokenizado
+	events map[string]*NetworkTokenLifecycleEvent // Por rede e identificador do evento
+	mutex  sync.RWMutex
+}
+
+// NewInMemoryNetworkTokenStore cria um novo armazenamento em memória
+func NewInMemoryNetworkTokenStore() *InMemoryNetworkTokenStore {
+	return &InMemoryNetworkTokenStore{
+		cards:  make(map[string]*CardToken),
+		events: make(map[string]*NetworkTokenLifecycleEvent),
+	}
+}
+
+// SaveCardToken grava uma cópia do cartão tokenizado
+func (s *InMemoryNetworkTokenStore) SaveCardToken(ctx context.Context, card *CardToken) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *card
+	s.cards[card.CardTokenID] = &copied
+	return nil
+}
+
+// GetCardToken recupera uma cópia do cartão tokenizado do tenant
+func (s *InMemoryNetworkTokenStore) GetCardToken(ctx context.Context, tenantID, cardTokenID string) (*CardToken, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	card, exists := s.cards[cardTokenID]
+	if !exists || card.TenantID != tenantID {
+		return nil, nil
+	}
+	copied := *card
+	return &copied, nil
+}
+
+// GetCardTokenByVaultToken recupera uma cópia do cartão associado ao token do cofre
+func (s *InMemoryNetworkTokenStore) GetCardTokenByVaultToken(ctx context.Context, tenantID, vaultTokenID string) (*CardToken, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, card := range s.cards {
+		if card.TenantID == tenantID && card.VaultTokenID == vaultTokenID {
+			copied := *card
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetCardTokenByReference recupera uma cópia do cartão com a referência do token na rede
+func (s *InMemoryNetworkTokenStore) GetCardTokenByReference(ctx context.Context, network, tokenReferenceID string) (*CardToken, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, card := range s.cards {
+		if card.Network == network && card.TokenReferenceID == tokenReferenceID {
+			copied := *card
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListCardTokens lista cópias dos cartões tokenizados do usuário
+func (s *InMemoryNetworkTokenStore) ListCardTokens(ctx context.Context, tenantID, userID string) ([]*CardToken, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cards := make([]*CardToken, 0)
+	for _, card := range s.cards {
+		if card.TenantID == tenantID && card.UserID == userID {
+			copied := *card
+			cards = append(cards, &copied)
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].CreatedAt.After(cards[j].CreatedAt)
+	})
+	return cards, nil
+}
+
+// RecordLifecycleEvent grava uma cópia do evento se ainda não tiver sido recebido
+func (s *InMemoryNetworkTokenStore) RecordLifecycleEvent(ctx context.Context, event *NetworkTokenLifecycleEvent) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := event.Network + "\x00" + event.EventID
+	if _, exists := s.events[key]; exists {
+		return false, nil
+	}
+	copied := *event
+	s.events[key] = &copied
+	return true, nil
+}
@@ -0,0 +1,320 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+)
+
+// Valores padrão da gestão de certificados Open Finance
+const (
+	DefaultOpenFinanceCertReloadInterval = 5 * time.Minute
+	DefaultOpenFinanceCertExpiryWarning  = 30 * 24 * time.Hour
+)
+
+// OpenFinanceCertificateConfig indica os certificados ICP-Brasil emitidos para o diretório
+type OpenFinanceCertificateConfig struct {
+	// Certificado de transporte (BRCAC) usado no mTLS com o diretório e as instituições
+	TransportCertFile string `json:"transport_cert_file"`
+	TransportKeyFile  string `json:"transport_key_file"`
+
+	// Chave de assinatura (BRSEAL) e o kid publicado no JWKS do software statement
+	SigningKeyFile string `json:"signing_key_file"`
+	SigningKeyID   string `json:"signing_key_id"`
+
+	// Cadeia de confiança das instituições participantes; vazio usa as raízes do sistema
+	RootCAFile string `json:"root_ca_file"`
+
+	// Intervalo de verificação de novos certificados em disco
+	ReloadInterval time.Duration `json:"reload_interval"`
+
+	// Antecedência com que a expiração do certificado de transporte é alertada
+	ExpiryWarning time.Duration `json:"expiry_warning"`
+}
+
+// openFinanceCertificates é o conjunto de certificados carregado num instante
+type openFinanceCertificates struct {
+	transport   *tls.Certificate
+	leaf        *x509.Certificate
+	signingKey  *rsa.PrivateKey
+	roots       *x509.CertPool
+	fingerprint string
+	modTimes    map[string]time.Time
+}
+
+// OpenFinanceCertificateManager carrega os certificados DCR e aplica rotações sem reiniciar o serviço
+type OpenFinanceCertificateManager struct {
+	config          OpenFinanceCertificateConfig
+	current         *openFinanceCertificates
+	mutex           sync.RWMutex
+	onRotate        []func(ctx context.Context)
+	logger          logging.Logger
+	metricsRecorder metrics.Metrics
+}
+
+// NewOpenFinanceCertificateManager cria o gestor e carrega os certificados atuais
+func NewOpenFinanceCertificateManager(config OpenFinanceCertificateConfig, logger logging.Logger, metricsRecorder metrics.Metrics) (*OpenFinanceCertificateManager, error) {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = DefaultOpenFinanceCertReloadInterval
+	}
+	if config.ExpiryWarning <= 0 {
+		config.ExpiryWarning = DefaultOpenFinanceCertExpiryWarning
+	}
+
+	m := &OpenFinanceCertificateManager{
+		config:          config,
+		logger:          logger,
+		metricsRecorder: metricsRecorder,
+	}
+
+	certs, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	m.current = certs
+
+	return m, nil
+}
+
+// OnRotate regista uma função chamada após cada rotação do certificado de transporte
+func (m *OpenFinanceCertificateManager) OnRotate(fn func(ctx context.Context)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.onRotate = append(m.onRotate, fn)
+}
+
+// Start verifica periodicamente os ficheiros de certificado até o contexto ser cancelado
+func (m *OpenFinanceCertificateManager) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := m.Reload(ctx); err != nil {
+				m.logger.WarnWithContext(ctx, "Falha ao recarregar certificados Open Finance",
+					"error", err.Error())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reload recarrega os certificados quando os ficheiros mudam e indica se houve rotação
+func (m *OpenFinanceCertificateManager) Reload(ctx context.Context) (bool, error) {
+	m.mutex.RLock()
+	previous := m.current
+	m.mutex.RUnlock()
+
+	if !m.filesChanged(previous) {
+		m.checkExpiry(ctx, previous)
+		return false, nil
+	}
+
+	certs, err := m.load()
+	if err != nil {
+		// Mantém os certificados anteriores enquanto os novos não forem válidos
+		return false, err
+	}
+
+	m.mutex.Lock()
+	m.current = certs
+	callbacks := append([]func(ctx context.Context){}, m.onRotate...)
+	m.mutex.Unlock()
+
+	rotated := certs.fingerprint != previous.fingerprint
+	if rotated {
+		m.logger.InfoWithContext(ctx, "Certificado de transporte Open Finance rodado",
+			"previous_fingerprint", previous.fingerprint,
+			"fingerprint", certs.fingerprint,
+			"not_after", certs.leaf.NotAfter)
+		m.metricsRecorder.CounterInc("payment_open_finance_certificate_rotations_total", nil)
+
+		for _, fn := range callbacks {
+			fn(ctx)
+		}
+	}
+	m.checkExpiry(ctx, certs)
+
+	return rotated, nil
+}
+
+// TLSConfig retorna a configuração mTLS que apresenta sempre o certificado de transporte atual
+func (m *OpenFinanceCertificateManager) TLSConfig() *tls.Config {
+	m.mutex.RLock()
+	roots := m.current.roots
+	m.mutex.RUnlock()
+
+	return &tls.Config{
+		MinVersion:    tls.VersionTLS12,
+		RootCAs:       roots,
+		Renegotiation: tls.RenegotiateOnceAsClient,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			m.mutex.RLock()
+			defer m.mutex.RUnlock()
+			return m.current.transport, nil
+		},
+	}
+}
+
+// HTTPClient cria um cliente HTTP com mTLS para o diretório e as instituições participantes
+func (m *OpenFinanceCertificateManager) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: m.TLSConfig()},
+	}
+}
+
+// SigningKey retorna a chave de assinatura das mensagens JWS e o respetivo kid
+func (m *OpenFinanceCertificateManager) SigningKey() (*rsa.PrivateKey, string) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.current.signingKey, m.config.SigningKeyID
+}
+
+// SubjectDN retorna o DN do certificado de transporte declarado no registo (tls_client_auth_subject_dn)
+func (m *OpenFinanceCertificateManager) SubjectDN() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.current.leaf.Subject.String()
+}
+
+// Fingerprint retorna a impressão digital SHA-256 do certificado de transporte atual
+func (m *OpenFinanceCertificateManager) Fingerprint() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.current.fingerprint
+}
+
+// load lê e valida os certificados configurados
+func (m *OpenFinanceCertificateManager) load() (*openFinanceCertificates, error) {
+	modTimes, err := m.modTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := tls.LoadX509KeyPair(m.config.TransportCertFile, m.config.TransportKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: falha ao carregar certificado de transporte: %v", ErrOpenFinanceCertificateUnavailable, err)
+	}
+	leaf, err := x509.ParseCertificate(transport.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: certificado de transporte inválido: %v", ErrOpenFinanceCertificateUnavailable, err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return nil, fmt.Errorf("%w: certificado de transporte expirado em %s", ErrOpenFinanceCertificateUnavailable, leaf.NotAfter.Format(time.RFC3339))
+	}
+	transport.Leaf = leaf
+
+	signingKey, err := loadOpenFinanceSigningKey(m.config.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots *x509.CertPool
+	if m.config.RootCAFile != "" {
+		pemData, err := os.ReadFile(m.config.RootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: falha ao ler cadeia de confiança: %v", ErrOpenFinanceCertificateUnavailable, err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("%w: cadeia de confiança sem certificados válidos", ErrOpenFinanceCertificateUnavailable)
+		}
+	}
+
+	digest := sha256.Sum256(leaf.Raw)
+	return &openFinanceCertificates{
+		transport:   &transport,
+		leaf:        leaf,
+		signingKey:  signingKey,
+		roots:       roots,
+		fingerprint: hex.EncodeToString(digest[:]),
+		modTimes:    modTimes,
+	}, nil
+}
+
+// modTimes obtém a data de modificação dos ficheiros de certificado
+func (m *OpenFinanceCertificateManager) modTimes() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, path := range []string{m.config.TransportCertFile, m.config.TransportKeyFile, m.config.SigningKeyFile, m.config.RootCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrOpenFinanceCertificateUnavailable, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// filesChanged verifica se algum ficheiro de certificado foi substituído desde o último carregamento
+func (m *OpenFinanceCertificateManager) filesChanged(certs *openFinanceCertificates) bool {
+	modTimes, err := m.modTimes()
+	if err != nil {
+		return false
+	}
+	for path, modTime := range modTimes {
+		if !certs.modTimes[path].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExpiry alerta quando o certificado de transporte se aproxima da expiração
+func (m *OpenFinanceCertificateManager) checkExpiry(ctx context.Context, certs *openFinanceCertificates) {
+	remaining := time.Until(certs.leaf.NotAfter)
+	m.metricsRecorder.HistogramObserve("payment_open_finance_certificate_days_remaining", remaining.Hours()/24, nil)
+
+	if remaining < m.config.ExpiryWarning {
+		m.logger.WarnWithContext(ctx, "Certificado de transporte Open Finance próximo da expiração",
+			"fingerprint", certs.fingerprint,
+			"not_after", certs.leaf.NotAfter)
+	}
+}
+
+// loadOpenFinanceSigningKey lê a chave RSA de assinatura (PKCS#1 ou PKCS#8)
+func loadOpenFinanceSigningKey(path string) (*rsa.PrivateKey, error) {
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: falha ao ler chave de assinatura: %v", ErrOpenFinanceCertificateUnavailable, err)
+	}
+
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("%w: chave de assinatura não está em formato PEM", ErrOpenFinanceCertificateUnavailable)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: chave de assinatura inválida: %v", ErrOpenFinanceCertificateUnavailable, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: a chave de assinatura deve ser RSA (PS256)", ErrOpenFinanceCertificateUnavailable)
+	}
+	return key, nil
+}
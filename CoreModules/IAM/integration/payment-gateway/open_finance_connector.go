@@ -0,0 +1,861 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Valores padrão do conector Open Finance
+const (
+	DefaultOpenFinanceHTTPTimeout     = 15 * time.Second
+	DefaultOpenFinanceParticipantsTTL = time.Hour
+	DefaultOpenFinanceConsentTTL      = 5 * time.Minute
+	DefaultOpenFinancePollInterval    = 2 * time.Second
+	DefaultOpenFinanceMaxPollInterval = time.Minute
+	DefaultOpenFinancePollTimeout     = 30 * time.Minute
+)
+
+// OpenFinanceConfig contém as configurações do conector de iniciação de pagamentos Open Finance Brasil
+type OpenFinanceConfig struct {
+	// Organização do gateway no diretório e software statement registado
+	OrganisationID       string `json:"organisation_id"`
+	DirectoryClientID    string `json:"directory_client_id"`
+	SoftwareStatementURL string `json:"software_statement_url"`
+	SoftwareJWKSURI      string `json:"software_jwks_uri"`
+
+	// Endpoints do diretório central
+	DirectoryParticipantsURL string `json:"directory_participants_url"`
+	DirectoryTokenURL        string `json:"directory_token_url"`
+
+	// Identificação do iniciador nos pagamentos (CNPJ e ISPB usados no endToEndId)
+	InitiatorCNPJ string `json:"initiator_cnpj"`
+	InitiatorISPB string `json:"initiator_ispb"`
+
+	// URI de retorno registada para o fluxo de autorização
+	RedirectURI string `json:"redirect_uri"`
+
+	// Certificados ICP-Brasil de transporte e assinatura
+	Certificates OpenFinanceCertificateConfig `json:"certificates"`
+
+	HTTPTimeout          time.Duration `json:"http_timeout"`
+	ParticipantsCacheTTL time.Duration `json:"participants_cache_ttl"`
+	ConsentTTL           time.Duration `json:"consent_ttl"`
+
+	// Resilience sobrepõe HTTPTimeout; só consultas e pedidos idempotentes são repetidos
+	Resilience resilience.Policy `json:"resilience"`
+
+	// Consulta do status dos pagamentos com recuo exponencial até um status final
+	PollInterval    time.Duration `json:"poll_interval"`
+	MaxPollInterval time.Duration `json:"max_poll_interval"`
+	PollTimeout     time.Duration `json:"poll_timeout"`
+}
+
+// openFinanceTokenResponse representa a resposta do endpoint de token
+type openFinanceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// openFinanceCachedToken guarda um token client_credentials até pouco antes de expirar
+type openFinanceCachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// openFinanceResponse representa as claims das respostas assinadas da API de Pagamentos
+type openFinanceResponse struct {
+	Iss  string          `json:"iss"`
+	Aud  string          `json:"aud"`
+	Data json.RawMessage `json:"data"`
+}
+
+// openFinanceIDToken representa as claims verificadas do id_token do fluxo híbrido (FAPI 1.0 Advanced)
+type openFinanceIDToken struct {
+	Iss   string          `json:"iss"`
+	Aud   json.RawMessage `json:"aud"`
+	Nonce string          `json:"nonce"`
+	Exp   int64           `json:"exp"`
+	CHash string          `json:"c_hash"`
+	SHash string          `json:"s_hash"`
+}
+
+// hasAudience indica se a claim aud, texto ou lista, inclui o cliente
+func (t *openFinanceIDToken) hasAudience(clientID string) bool {
+	var single string
+	if err := json.Unmarshal(t.Aud, &single); err == nil {
+		return single == clientID
+	}
+	var list []string
+	if err := json.Unmarshal(t.Aud, &list); err == nil {
+		for _, aud := range list {
+			if aud == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// openFinancePaymentData representa os campos usados de um pagamento PIX retornado pela instituição
+type openFinancePaymentData struct {
+	PaymentID       string                   `json:"paymentId"`
+	EndToEndID      string                   `json:"endToEndId"`
+	Status          OpenFinancePaymentStatus `json:"status"`
+	RejectionReason *struct {
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	} `json:"rejectionReason,omitempty"`
+}
+
+// OpenFinanceConnector inicia pagamentos PIX nas instituições detentoras de conta via Open Finance Brasil
+type OpenFinanceConnector struct {
+	config       OpenFinanceConfig
+	store        OpenFinanceStore
+	certificates *OpenFinanceCertificateManager
+	httpClient   *http.Client
+	policy       resilience.Policy
+
+	participants          map[string]*OpenFinanceParticipant
+	participantsFetchedAt time.Time
+	discoveries           map[string]*openFinanceDiscovery
+	keySets               map[string]*openFinanceJWKS
+	tokens                map[string]openFinanceCachedToken
+	cacheMutex            sync.Mutex
+	registerMutex         sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewOpenFinanceConnector cria o conector e carrega os certificados DCR
+func NewOpenFinanceConnector(config OpenFinanceConfig, store OpenFinanceStore) (*OpenFinanceConnector, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-open-finance",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.HTTPTimeout <= 0 {
+		config.HTTPTimeout = DefaultOpenFinanceHTTPTimeout
+	}
+	if config.ParticipantsCacheTTL <= 0 {
+		config.ParticipantsCacheTTL = DefaultOpenFinanceParticipantsTTL
+	}
+	if config.ConsentTTL <= 0 {
+		config.ConsentTTL = DefaultOpenFinanceConsentTTL
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultOpenFinancePollInterval
+	}
+	if config.MaxPollInterval <= 0 {
+		config.MaxPollInterval = DefaultOpenFinanceMaxPollInterval
+	}
+	if config.PollTimeout <= 0 {
+		config.PollTimeout = DefaultOpenFinancePollTimeout
+	}
+	if len(config.InitiatorISPB) != 8 {
+		return nil, fmt.Errorf("ISPB do iniciador deve ter 8 dígitos: %q", config.InitiatorISPB)
+	}
+
+	certificates, err := NewOpenFinanceCertificateManager(config.Certificates, obsAdapter.Logger(), obsAdapter.Metrics())
+	if err != nil {
+		return nil, err
+	}
+
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: config.HTTPTimeout}).Merge(config.Resilience)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	connector := &OpenFinanceConnector{
+		config:          config,
+		store:           store,
+		certificates:    certificates,
+		httpClient:      certificates.HTTPClient(policy.Timeout),
+		policy:          policy,
+		discoveries:     make(map[string]*openFinanceDiscovery),
+		keySets:         make(map[string]*openFinanceJWKS),
+		tokens:          make(map[string]openFinanceCachedToken),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+
+	// Os registos DCR acompanham o certificado de transporte; os tokens emitidos para o anterior deixam de valer
+	certificates.OnRotate(func(ctx context.Context) {
+		connector.cacheMutex.Lock()
+		connector.tokens = make(map[string]openFinanceCachedToken)
+		connector.cacheMutex.Unlock()
+		connector.UpdateRegistrations(ctx)
+	})
+
+	return connector, nil
+}
+
+// Start inicia a verificação periódica de rotação dos certificados
+func (c *OpenFinanceConnector) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.certificates.Start(c.ctx)
+	}()
+
+	c.logger.Info("Conector Open Finance iniciado", "organisation_id", c.config.OrganisationID)
+}
+
+// Stop interrompe a verificação de certificados e as consultas de status em curso
+func (c *OpenFinanceConnector) Stop() error {
+	c.cancel()
+	c.wg.Wait()
+	return nil
+}
+
+// CreatePaymentConsent cria o consentimento de pagamento na instituição detentora e retorna a URL de autorização
+func (c *OpenFinanceConnector) CreatePaymentConsent(ctx context.Context, req *OpenFinancePaymentInitiationRequest) (*OpenFinancePaymentConsent, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "OpenFinanceConnector.CreatePaymentConsent")
+	defer span.End()
+
+	if err := validateOpenFinanceRequest(req); err != nil {
+		return nil, err
+	}
+
+	participant, err := c.participant(ctx, req.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+	registration, err := c.ensureRegistration(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.clientCredentialsToken(ctx, participant, registration)
+	if err != nil {
+		return nil, err
+	}
+
+	consentsURL := participant.PaymentsAPIURL + openFinanceConsentsPath
+	claims := c.requestClaims(consentsURL, map[string]interface{}{
+		"loggedUser": map[string]interface{}{
+			"document": map[string]string{"identification": req.DebtorDocument, "rel": "CPF"},
+		},
+		"creditor": map[string]string{
+			"personType": openFinancePersonType(req.CreditorDocument),
+			"cpfCnpj":    req.CreditorDocument,
+			"name":       req.CreditorName,
+		},
+		"payment": map[string]interface{}{
+			"type":     "PIX",
+			"date":     openFinanceBusinessDate(time.Now()),
+			"currency": "BRL",
+			"amount":   fmt.Sprintf("%.2f", req.Amount),
+			"details":  openFinancePaymentDetails(req),
+		},
+	})
+
+	var consentData struct {
+		ConsentID string                   `json:"consentId"`
+		Status    OpenFinanceConsentStatus `json:"status"`
+	}
+	if err := c.postSigned(ctx, participant, consentsURL, token, claims, &consentData); err != nil {
+		c.metricsRecorder.CounterInc("payment_open_finance_consents_total", map[string]string{
+			"organisation_id": participant.OrganisationID,
+			"result":          "failed",
+		})
+		return nil, fmt.Errorf("falha ao criar consentimento de pagamento: %w", err)
+	}
+
+	now := time.Now()
+	consent := &OpenFinancePaymentConsent{
+		ConsentID:      consentData.ConsentID,
+		TenantID:       req.TenantID,
+		MerchantID:     req.MerchantID,
+		TransactionID:  req.TransactionID,
+		OrganisationID: participant.OrganisationID,
+		Status:         consentData.Status,
+		Request:        *req,
+		State:          randomOpenFinanceValue(),
+		Nonce:          randomOpenFinanceValue(),
+		CodeVerifier:   randomOpenFinanceValue(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      now.Add(c.config.ConsentTTL),
+	}
+
+	consent.AuthorizationURL, err = c.pushAuthorizationRequest(ctx, participant, registration, consent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.SaveConsent(ctx, consent); err != nil {
+		return nil, fmt.Errorf("falha ao gravar consentimento: %w", err)
+	}
+
+	c.metricsRecorder.CounterInc("payment_open_finance_consents_total", map[string]string{
+		"organisation_id": participant.OrganisationID,
+		"result":          "created",
+	})
+	c.logger.InfoWithContext(ctx, "Consentimento Open Finance criado, aguardando autorização do titular",
+		"consent_id", consent.ConsentID,
+		"tenant_id", consent.TenantID,
+		"transaction_id", consent.TransactionID,
+		"organisation_id", consent.OrganisationID)
+
+	return consent, nil
+}
+
+// HandleRedirect conclui a autorização do titular e inicia o pagamento PIX consentido
+// Os parâmetros do fluxo híbrido chegam no fragmento e são reenviados pela página de retorno
+func (c *OpenFinanceConnector) HandleRedirect(ctx context.Context, state, code, idToken, authError, authErrorDescription string) (*OpenFinancePaymentConsent, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "OpenFinanceConnector.HandleRedirect")
+	defer span.End()
+
+	if state == "" {
+		return nil, ErrOpenFinanceInvalidState
+	}
+	// O state é consumido antes de qualquer outra operação: só pode ser usado uma vez,
+	// mesmo por redirecionamentos concorrentes
+	consent, err := c.store.ConsumeState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if authError == "" && time.Now().After(consent.ExpiresAt) {
+		authError, authErrorDescription = "consent_expired", "Consentimento expirou antes da autorização"
+	}
+	if authError == "" && (code == "" || idToken == "") {
+		authError, authErrorDescription = "invalid_request", "Redirecionamento sem code ou id_token"
+	}
+	if authError != "" {
+		return c.rejectConsent(ctx, consent, strings.TrimSpace(authError+" "+authErrorDescription))
+	}
+
+	participant, err := c.participant(ctx, consent.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+	registration, err := c.ensureRegistration(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := c.discovery(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+
+	// O code só é trocado depois de validado o id_token que o acompanha (proteção contra injeção de code)
+	if err := c.verifyIDToken(ctx, participant, registration, doc, consent, idToken, code, state); err != nil {
+		c.logger.WarnWithContext(ctx, "id_token do redirecionamento Open Finance rejeitado",
+			"consent_id", consent.ConsentID,
+			"error", err.Error())
+		return c.rejectConsent(ctx, consent, "invalid_id_token")
+	}
+
+	token, err := c.requestToken(ctx, doc.tokenEndpoint(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURI},
+		"code_verifier": {consent.CodeVerifier},
+		"client_id":     {registration.ClientID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao trocar código de autorização: %w", err)
+	}
+	consent.Status = OpenFinanceConsentAuthorised
+
+	if err := c.initiatePayment(ctx, participant, consent, token.AccessToken); err != nil {
+		consent.UpdatedAt = time.Now()
+		c.store.SaveConsent(ctx, consent)
+		return nil, err
+	}
+
+	c.wg.Add(1)
+	go c.pollPayment(consent.TenantID, consent.ConsentID)
+
+	return consent, nil
+}
+
+// rejectConsent grava o consentimento como rejeitado e retorna ErrOpenFinanceConsentRejected
+func (c *OpenFinanceConnector) rejectConsent(ctx context.Context, consent *OpenFinancePaymentConsent, reason string) (*OpenFinancePaymentConsent, error) {
+	consent.Status = OpenFinanceConsentRejected
+	consent.RejectionReason = reason
+	consent.UpdatedAt = time.Now()
+	if err := c.store.SaveConsent(ctx, consent); err != nil {
+		return nil, err
+	}
+
+	c.metricsRecorder.CounterInc("payment_open_finance_consents_total", map[string]string{
+		"organisation_id": consent.OrganisationID,
+		"result":          "rejected",
+	})
+	c.logger.WarnWithContext(ctx, "Consentimento Open Finance não autorizado",
+		"consent_id", consent.ConsentID,
+		"transaction_id", consent.TransactionID,
+		"reason", consent.RejectionReason)
+	return consent, ErrOpenFinanceConsentRejected
+}
+
+// verifyIDToken valida o id_token do fluxo híbrido: assinatura pelo servidor de autorização,
+// emissor, audiência, nonce, validade e os hashes que o ligam ao code (c_hash) e ao state (s_hash)
+func (c *OpenFinanceConnector) verifyIDToken(ctx context.Context, participant *OpenFinanceParticipant, registration *OpenFinanceClientRegistration, doc *openFinanceDiscovery, consent *OpenFinancePaymentConsent, idToken, code, state string) error {
+	var claims openFinanceIDToken
+	if err := c.verifyParticipantJWT(ctx, participant, idToken, &claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrOpenFinanceInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Iss == "" || claims.Iss != doc.Issuer:
+		return fmt.Errorf("%w: emissor %q não corresponde ao servidor de autorização", ErrOpenFinanceInvalidIDToken, claims.Iss)
+	case !claims.hasAudience(registration.ClientID):
+		return fmt.Errorf("%w: audiência não inclui o cliente %s", ErrOpenFinanceInvalidIDToken, registration.ClientID)
+	case claims.Nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(consent.Nonce)) != 1:
+		return fmt.Errorf("%w: nonce não corresponde ao pedido de autorização", ErrOpenFinanceInvalidIDToken)
+	case claims.Exp == 0 || time.Now().Unix() >= claims.Exp:
+		return fmt.Errorf("%w: token expirado", ErrOpenFinanceInvalidIDToken)
+	case claims.CHash == "" || subtle.ConstantTimeCompare([]byte(claims.CHash), []byte(openFinanceHalfHash(code))) != 1:
+		return fmt.Errorf("%w: c_hash não corresponde ao code recebido", ErrOpenFinanceInvalidIDToken)
+	case claims.SHash == "" || subtle.ConstantTimeCompare([]byte(claims.SHash), []byte(openFinanceHalfHash(state))) != 1:
+		return fmt.Errorf("%w: s_hash não corresponde ao state recebido", ErrOpenFinanceInvalidIDToken)
+	}
+	return nil
+}
+
+// GetPaymentConsent retorna o consentimento e o último status conhecido do pagamento
+func (c *OpenFinanceConnector) GetPaymentConsent(ctx context.Context, tenantID, consentID string) (*OpenFinancePaymentConsent, error) {
+	return c.store.GetConsent(ctx, tenantID, consentID)
+}
+
+// RefreshPaymentStatus consulta o status do pagamento na instituição detentora
+func (c *OpenFinanceConnector) RefreshPaymentStatus(ctx context.Context, tenantID, consentID string) (*OpenFinancePaymentConsent, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "OpenFinanceConnector.RefreshPaymentStatus")
+	defer span.End()
+
+	consent, err := c.store.GetConsent(ctx, tenantID, consentID)
+	if err != nil {
+		return nil, err
+	}
+	if consent.PaymentID == "" || consent.PaymentStatus.IsFinal() {
+		return consent, nil
+	}
+
+	participant, err := c.participant(ctx, consent.OrganisationID)
+	if err != nil {
+		return nil, err
+	}
+	registration, err := c.ensureRegistration(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.clientCredentialsToken(ctx, participant, registration)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment openFinancePaymentData
+	paymentURL := participant.PaymentsAPIURL + openFinancePixPaymentsPath + "/" + url.PathEscape(consent.PaymentID)
+	if err := c.getSigned(ctx, participant, paymentURL, token, &payment); err != nil {
+		return nil, fmt.Errorf("falha ao consultar status do pagamento: %w", err)
+	}
+
+	if payment.Status != consent.PaymentStatus {
+		c.applyPaymentStatus(ctx, consent, &payment)
+		if err := c.store.SaveConsent(ctx, consent); err != nil {
+			return nil, err
+		}
+	}
+
+	return consent, nil
+}
+
+// initiatePayment cria o pagamento PIX na instituição detentora com o token autorizado pelo titular
+func (c *OpenFinanceConnector) initiatePayment(ctx context.Context, participant *OpenFinanceParticipant, consent *OpenFinancePaymentConsent, accessToken string) error {
+	req := consent.Request
+	endToEndID := newOpenFinanceEndToEndID(c.config.InitiatorISPB, time.Now())
+
+	paymentsURL := participant.PaymentsAPIURL + openFinancePixPaymentsPath
+	details := openFinancePaymentDetails(&req)
+	payment := map[string]interface{}{
+		"endToEndId":      endToEndID,
+		"localInstrument": details["localInstrument"],
+		"payment": map[string]string{
+			"amount":   fmt.Sprintf("%.2f", req.Amount),
+			"currency": "BRL",
+		},
+		"creditorAccount":   req.CreditorAccount,
+		"cnpjInitiator":     c.config.InitiatorCNPJ,
+		"consentId":         consent.ConsentID,
+		"authorisationFlow": "HYBRID_FLOW",
+	}
+	if req.ProxyKey != "" {
+		payment["proxy"] = req.ProxyKey
+	}
+	if req.Remittance != "" {
+		payment["remittanceInformation"] = req.Remittance
+	}
+
+	var created []openFinancePaymentData
+	if err := c.postSigned(ctx, participant, paymentsURL, accessToken, c.requestClaims(paymentsURL, []interface{}{payment}), &created); err != nil {
+		c.metricsRecorder.CounterInc("payment_open_finance_payments_total", map[string]string{
+			"organisation_id": participant.OrganisationID,
+			"status":          "failed",
+		})
+		return fmt.Errorf("falha ao iniciar pagamento PIX: %w", err)
+	}
+	if len(created) == 0 {
+		return errors.New("instituição detentora não retornou o pagamento iniciado")
+	}
+
+	consent.Status = OpenFinanceConsentConsumed
+	consent.EndToEndID = endToEndID
+	consent.PaymentID = created[0].PaymentID
+	c.applyPaymentStatus(ctx, consent, &created[0])
+
+	if err := c.store.SaveConsent(ctx, consent); err != nil {
+		return fmt.Errorf("falha ao gravar pagamento iniciado: %w", err)
+	}
+
+	c.logger.InfoWithContext(ctx, "Pagamento PIX iniciado via Open Finance",
+		"consent_id", consent.ConsentID,
+		"payment_id", consent.PaymentID,
+		"end_to_end_id", consent.EndToEndID,
+		"status", string(consent.PaymentStatus))
+
+	return nil
+}
+
+// applyPaymentStatus atualiza o consentimento com o status retornado pela instituição
+func (c *OpenFinanceConnector) applyPaymentStatus(ctx context.Context, consent *OpenFinancePaymentConsent, payment *openFinancePaymentData) {
+	consent.PaymentStatus = payment.Status
+	consent.UpdatedAt = time.Now()
+	if payment.RejectionReason != nil {
+		consent.RejectionReason = strings.TrimSpace(payment.RejectionReason.Code + " " + payment.RejectionReason.Detail)
+	}
+
+	c.metricsRecorder.CounterInc("payment_open_finance_payments_total", map[string]string{
+		"organisation_id": consent.OrganisationID,
+		"status":          string(payment.Status),
+	})
+}
+
+// pollPayment consulta o status do pagamento com recuo exponencial até um status final ou o tempo limite
+func (c *OpenFinanceConnector) pollPayment(tenantID, consentID string) {
+	defer c.wg.Done()
+
+	interval := c.config.PollInterval
+	deadline := time.Now().Add(c.config.PollTimeout)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-time.After(interval):
+		case <-c.ctx.Done():
+			return
+		}
+
+		consent, err := c.RefreshPaymentStatus(c.ctx, tenantID, consentID)
+		if err != nil {
+			c.logger.WarnWithContext(c.ctx, "Falha ao consultar status do pagamento Open Finance",
+				"consent_id", consentID,
+				"error", err.Error())
+		} else if consent.PaymentStatus.IsFinal() {
+			c.logger.InfoWithContext(c.ctx, "Pagamento Open Finance concluído",
+				"consent_id", consentID,
+				"payment_id", consent.PaymentID,
+				"status", string(consent.PaymentStatus))
+			return
+		}
+
+		interval *= 2
+		if interval > c.config.MaxPollInterval {
+			interval = c.config.MaxPollInterval
+		}
+	}
+
+	c.logger.WarnWithContext(c.ctx, "Tempo limite de consulta do pagamento Open Finance excedido",
+		"consent_id", consentID)
+}
+
+// pushAuthorizationRequest envia o request object assinado (PAR) e monta a URL de autorização
+func (c *OpenFinanceConnector) pushAuthorizationRequest(ctx context.Context, participant *OpenFinanceParticipant, registration *OpenFinanceClientRegistration, consent *OpenFinancePaymentConsent) (string, error) {
+	doc, err := c.discovery(ctx, participant)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(consent.CodeVerifier))
+	now := time.Now()
+	key, kid := c.certificates.SigningKey()
+	requestObject, err := signOpenFinanceJWT(key, kid, map[string]interface{}{
+		"iss":                   registration.ClientID,
+		"aud":                   doc.Issuer,
+		"client_id":             registration.ClientID,
+		"response_type":         "code id_token",
+		"redirect_uri":          c.config.RedirectURI,
+		"scope":                 "openid payments consent:" + consent.ConsentID,
+		"state":                 consent.State,
+		"nonce":                 consent.Nonce,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+		"jti":                   uuid.New().String(),
+		"iat":                   now.Unix(),
+		"nbf":                   now.Unix(),
+		"exp":                   now.Add(5 * time.Minute).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"client_id": {registration.ClientID},
+		"request":   {requestObject},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.parEndpoint(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("falha no pushed authorization request: %w", err)
+	}
+	var par struct {
+		RequestURI string `json:"request_uri"`
+	}
+	if err := json.Unmarshal(body, &par); err != nil || par.RequestURI == "" {
+		return "", errors.New("resposta de pushed authorization request sem request_uri")
+	}
+
+	query := url.Values{
+		"client_id":   {registration.ClientID},
+		"request_uri": {par.RequestURI},
+	}
+	return doc.AuthorizationEndpoint + "?" + query.Encode(), nil
+}
+
+// clientCredentialsToken obtém (ou reutiliza) um token client_credentials com escopo de pagamentos
+func (c *OpenFinanceConnector) clientCredentialsToken(ctx context.Context, participant *OpenFinanceParticipant, registration *OpenFinanceClientRegistration) (string, error) {
+	c.cacheMutex.Lock()
+	cached, ok := c.tokens[participant.OrganisationID]
+	c.cacheMutex.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.accessToken, nil
+	}
+
+	doc, err := c.discovery(ctx, participant)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := c.requestToken(ctx, doc.tokenEndpoint(), url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"payments"},
+		"client_id":  {registration.ClientID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("falha ao obter token do participante %s: %w", participant.OrganisationID, err)
+	}
+
+	// Margem para não usar um token que expire durante a requisição
+	expiresIn := time.Duration(token.ExpiresIn)*time.Second - 30*time.Second
+	if expiresIn > 0 {
+		c.cacheMutex.Lock()
+		c.tokens[participant.OrganisationID] = openFinanceCachedToken{
+			accessToken: token.AccessToken,
+			expiresAt:   time.Now().Add(expiresIn),
+		}
+		c.cacheMutex.Unlock()
+	}
+
+	return token.AccessToken, nil
+}
+
+// requestToken chama um endpoint de token autenticando o cliente por mTLS (tls_client_auth)
+func (c *OpenFinanceConnector) requestToken(ctx context.Context, endpoint string, form url.Values) (*openFinanceTokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var token openFinanceTokenResponse
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return nil, errors.New("resposta de token sem access_token")
+	}
+	return &token, nil
+}
+
+// requestClaims envolve os dados do pedido nas claims exigidas para corpos assinados
+func (c *OpenFinanceConnector) requestClaims(audience string, data interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"aud":  audience,
+		"iss":  c.config.OrganisationID,
+		"iat":  time.Now().Unix(),
+		"jti":  uuid.New().String(),
+		"data": data,
+	}
+}
+
+// postSigned envia um corpo JWS (application/jwt) e valida a resposta assinada pelo participante
+func (c *OpenFinanceConnector) postSigned(ctx context.Context, participant *OpenFinanceParticipant, endpoint, accessToken string, claims map[string]interface{}, out interface{}) error {
+	key, kid := c.certificates.SigningKey()
+	body, err := signOpenFinanceJWT(key, kid, claims)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/jwt")
+	req.Header.Set("x-idempotency-key", claims["jti"].(string))
+
+	return c.sendSigned(ctx, participant, req, accessToken, out)
+}
+
+// getSigned consulta um recurso cuja resposta é assinada pelo participante
+func (c *OpenFinanceConnector) getSigned(ctx context.Context, participant *OpenFinanceParticipant, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.sendSigned(ctx, participant, req, accessToken, out)
+}
+
+// sendSigned executa a requisição e valida assinatura, emissor e audiência da resposta
+func (c *OpenFinanceConnector) sendSigned(ctx context.Context, participant *OpenFinanceParticipant, req *http.Request, accessToken string, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/jwt")
+	req.Header.Set("x-fapi-interaction-id", uuid.New().String())
+
+	body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	var resp openFinanceResponse
+	if err := c.verifyParticipantJWT(ctx, participant, string(body), &resp); err != nil {
+		return err
+	}
+
+	// Emissor e audiência são obrigatórios: uma resposta assinada para outro destinatário não é aceite
+	if resp.Iss != participant.OrganisationID {
+		return fmt.Errorf("%w: emissor %q não corresponde ao participante", ErrOpenFinanceInvalidSignature, resp.Iss)
+	}
+	if resp.Aud != c.config.OrganisationID {
+		return fmt.Errorf("%w: audiência %q não corresponde ao iniciador", ErrOpenFinanceInvalidSignature, resp.Aud)
+	}
+
+	return json.Unmarshal(resp.Data, out)
+}
+
+// verifyParticipantJWT valida um JWS assinado pelo participante com as chaves do seu JWKS
+func (c *OpenFinanceConnector) verifyParticipantJWT(ctx context.Context, participant *OpenFinanceParticipant, token string, claims interface{}) error {
+	keySet, err := c.jwks(ctx, participant, false)
+	if err != nil {
+		return err
+	}
+	if err := verifyOpenFinanceJWT(token, keySet, claims); err != nil {
+		// O participante pode ter rodado as chaves desde a última consulta do JWKS
+		if keySet, err = c.jwks(ctx, participant, true); err != nil {
+			return err
+		}
+		return verifyOpenFinanceJWT(token, keySet, claims)
+	}
+	return nil
+}
+
+// validateOpenFinanceRequest valida o pedido de iniciação de pagamento
+func validateOpenFinanceRequest(req *OpenFinancePaymentInitiationRequest) error {
+	switch {
+	case req.TenantID == "":
+		return errors.New("tenant_id é obrigatório")
+	case req.OrganisationID == "":
+		return errors.New("organisation_id da instituição detentora é obrigatório")
+	case req.Amount <= 0:
+		return errors.New("valor do pagamento deve ser positivo")
+	case len(req.DebtorDocument) != 11:
+		return errors.New("debtor_document deve ser um CPF com 11 dígitos")
+	case len(req.CreditorDocument) != 11 && len(req.CreditorDocument) != 14:
+		return errors.New("creditor_document deve ser um CPF ou CNPJ")
+	case req.CreditorName == "":
+		return errors.New("creditor_name é obrigatório")
+	case req.CreditorAccount.ISPB == "" || req.CreditorAccount.Number == "" || req.CreditorAccount.AccountType == "":
+		return errors.New("creditor_account incompleta")
+	}
+	return nil
+}
+
+// openFinancePaymentDetails monta os detalhes PIX: DICT quando há chave, MANU com os dados da conta
+func openFinancePaymentDetails(req *OpenFinancePaymentInitiationRequest) map[string]interface{} {
+	details := map[string]interface{}{
+		"localInstrument": "MANU",
+		"creditorAccount": req.CreditorAccount,
+	}
+	if req.ProxyKey != "" {
+		details["localInstrument"] = "DICT"
+		details["proxy"] = req.ProxyKey
+	}
+	return details
+}
+
+// openFinancePersonType deriva o tipo de pessoa do recebedor a partir do documento
+func openFinancePersonType(document string) string {
+	if len(document) == 11 {
+		return "PESSOA_NATURAL"
+	}
+	return "PESSOA_JURIDICA"
+}
+
+// openFinanceBusinessDate retorna a data do pagamento no fuso de Brasília, exigida pela especificação
+func openFinanceBusinessDate(now time.Time) string {
+	if location, err := time.LoadLocation("America/Sao_Paulo"); err == nil {
+		now = now.In(location)
+	}
+	return now.Format("2006-01-02")
+}
+
+// newOpenFinanceEndToEndID gera o identificador fim a fim PIX: E + ISPB + AAAAMMDDHHMM + 11 caracteres
+func newOpenFinanceEndToEndID(ispb string, now time.Time) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	suffix := make([]byte, 11)
+	rand.Read(suffix)
+	for i := range suffix {
+		suffix[i] = alphabet[int(suffix[i])%len(alphabet)]
+	}
+	return "E" + ispb + now.UTC().Format("200601021504") + string(suffix)
+}
+
+// randomOpenFinanceValue gera valores aleatórios para state, nonce e code_verifier
+func randomOpenFinanceValue() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
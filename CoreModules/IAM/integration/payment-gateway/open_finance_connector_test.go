@@ -0,0 +1,483 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOpenFinanceGatewayOrg = "gateway-org"
+	testOpenFinanceGatewayKID = "gateway-key"
+	testOpenFinanceBankOrg    = "bank-org"
+	testOpenFinanceBankKID    = "bank-key"
+	testOpenFinanceClientID   = "client-1"
+	testOpenFinanceState      = "state-1"
+	testOpenFinanceNonce      = "nonce-1"
+	testOpenFinanceCode       = "code-1"
+)
+
+// openFinanceTestJWK publica a chave pública no formato do JWKS dos participantes
+func openFinanceTestJWK(key *rsa.PublicKey, kid string) openFinanceJWK {
+	return openFinanceJWK{
+		Kid: kid,
+		Kty: "RSA",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// fakeOpenFinanceBank simula o diretório e a instituição detentora: discovery, JWKS, token e API de pagamentos
+type fakeOpenFinanceBank struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	gatewayKey *rsa.PrivateKey
+
+	mu             sync.Mutex
+	tokenCalls     int
+	payments       []map[string]interface{}
+	responseClaims func(claims map[string]interface{})
+}
+
+func newFakeOpenFinanceBank(t *testing.T) *fakeOpenFinanceBank {
+	t.Helper()
+
+	bankKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	gatewayKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	bank := &fakeOpenFinanceBank{key: bankKey, gatewayKey: gatewayKey}
+	bank.server = httptest.NewTLSServer(http.HandlerFunc(bank.serve))
+	t.Cleanup(bank.server.Close)
+	return bank
+}
+
+func (b *fakeOpenFinanceBank) serve(w http.ResponseWriter, r *http.Request) {
+	base := b.server.URL
+	switch {
+	case r.URL.Path == "/participants":
+		json.NewEncoder(w).Encode([]map[string]interface{}{{
+			"OrganisationId":   testOpenFinanceBankOrg,
+			"OrganisationName": "Banco de Teste",
+			"AuthorisationServers": []map[string]interface{}{{
+				"OpenIDDiscoveryDocument": base + "/.well-known/openid-configuration",
+				"ApiResources": []map[string]interface{}{{
+					"ApiFamilyType": openFinancePaymentsAPIFamily,
+					"ApiDiscoveryEndpoints": []map[string]string{
+						{"ApiEndpoint": base + openFinanceConsentsPath},
+					},
+				}},
+			}},
+		}})
+	case r.URL.Path == "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 base,
+			"authorization_endpoint": base + "/authorize",
+			"token_endpoint":         base + "/token",
+			"jwks_uri":               base + "/jwks",
+		})
+	case r.URL.Path == "/jwks":
+		json.NewEncoder(w).Encode(openFinanceJWKS{Keys: []openFinanceJWK{openFinanceTestJWK(&b.key.PublicKey, testOpenFinanceBankKID)}})
+	case r.URL.Path == "/token":
+		b.mu.Lock()
+		b.tokenCalls++
+		b.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-1", "expires_in": 300})
+	case r.URL.Path == openFinancePixPaymentsPath && r.Method == http.MethodPost:
+		b.servePayment(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// servePayment valida o pedido assinado pelo gateway e responde com o pagamento assinado pela instituição
+func (b *fakeOpenFinanceBank) servePayment(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	gatewayKeys := &openFinanceJWKS{Keys: []openFinanceJWK{openFinanceTestJWK(&b.gatewayKey.PublicKey, testOpenFinanceGatewayKID)}}
+	var request map[string]interface{}
+	if err := verifyOpenFinanceJWT(string(body), gatewayKeys, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	b.payments = append(b.payments, request)
+	adjust := b.responseClaims
+	b.mu.Unlock()
+
+	claims := map[string]interface{}{
+		"iss":  testOpenFinanceBankOrg,
+		"aud":  testOpenFinanceGatewayOrg,
+		"iat":  time.Now().Unix(),
+		"data": []map[string]string{{"paymentId": "pay-1", "status": string(OpenFinancePaymentReceived)}},
+	}
+	if adjust != nil {
+		adjust(claims)
+	}
+	signed, err := signOpenFinanceJWT(b.key, testOpenFinanceBankKID, claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jwt")
+	io.WriteString(w, signed)
+}
+
+// idToken emite o id_token do fluxo híbrido; overrides com valor nil removem a claim
+func (b *fakeOpenFinanceBank) idToken(t *testing.T, key *rsa.PrivateKey, overrides map[string]interface{}) string {
+	t.Helper()
+
+	claims := map[string]interface{}{
+		"iss":    b.server.URL,
+		"aud":    testOpenFinanceClientID,
+		"sub":    "titular-1",
+		"nonce":  testOpenFinanceNonce,
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(5 * time.Minute).Unix(),
+		"c_hash": openFinanceHalfHash(testOpenFinanceCode),
+		"s_hash": openFinanceHalfHash(testOpenFinanceState),
+	}
+	for claim, value := range overrides {
+		if value == nil {
+			delete(claims, claim)
+			continue
+		}
+		claims[claim] = value
+	}
+
+	token, err := signOpenFinanceJWT(key, testOpenFinanceBankKID, claims)
+	require.NoError(t, err)
+	return token
+}
+
+func (b *fakeOpenFinanceBank) counts() (tokenCalls, payments int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokenCalls, len(b.payments)
+}
+
+// writeOpenFinanceTestCertificates grava o certificado de transporte autoassinado e a chave de assinatura do gateway
+func writeOpenFinanceTestCertificates(t *testing.T, key *rsa.PrivateKey) OpenFinanceCertificateConfig {
+	t.Helper()
+	dir := t.TempDir()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: testOpenFinanceGatewayOrg},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	config := OpenFinanceCertificateConfig{
+		TransportCertFile: filepath.Join(dir, "transport.pem"),
+		TransportKeyFile:  filepath.Join(dir, "transport.key"),
+		SigningKeyFile:    filepath.Join(dir, "signing.key"),
+		SigningKeyID:      testOpenFinanceGatewayKID,
+	}
+	require.NoError(t, os.WriteFile(config.TransportCertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(config.TransportKeyFile, keyPEM, 0600))
+	require.NoError(t, os.WriteFile(config.SigningKeyFile, keyPEM, 0600))
+	return config
+}
+
+// newTestOpenFinanceConnector cria o conector ligado à instituição simulada, já registado nela
+// e com um consentimento a aguardar a autorização do titular
+func newTestOpenFinanceConnector(t *testing.T, bank *fakeOpenFinanceBank) (*OpenFinanceConnector, *InMemoryOpenFinanceStore, *OpenFinancePaymentConsent) {
+	t.Helper()
+	ctx := context.Background()
+
+	store := NewInMemoryOpenFinanceStore()
+	connector, err := NewOpenFinanceConnector(OpenFinanceConfig{
+		OrganisationID:           testOpenFinanceGatewayOrg,
+		DirectoryParticipantsURL: bank.server.URL + "/participants",
+		InitiatorCNPJ:            "12345678000190",
+		InitiatorISPB:            "12345678",
+		RedirectURI:              "https://gateway.example/open-finance/redirect",
+		Certificates:             writeOpenFinanceTestCertificates(t, bank.gatewayKey),
+		PollInterval:             time.Hour,
+		PollTimeout:              time.Hour,
+	}, store)
+	require.NoError(t, err)
+	connector.httpClient = bank.server.Client()
+	t.Cleanup(func() { connector.Stop() })
+
+	require.NoError(t, store.SaveRegistration(ctx, &OpenFinanceClientRegistration{
+		OrganisationID: testOpenFinanceBankOrg,
+		ClientID:       testOpenFinanceClientID,
+	}))
+
+	now := time.Now()
+	consent := &OpenFinancePaymentConsent{
+		ConsentID:      "consent-1",
+		TenantID:       "tenant-1",
+		TransactionID:  "tx-1",
+		OrganisationID: testOpenFinanceBankOrg,
+		Status:         OpenFinanceConsentAwaitingAuthorisation,
+		Request: OpenFinancePaymentInitiationRequest{
+			TenantID:         "tenant-1",
+			TransactionID:    "tx-1",
+			OrganisationID:   testOpenFinanceBankOrg,
+			Amount:           150.75,
+			DebtorDocument:   "12345678901",
+			CreditorName:     "Loja Exemplo",
+			CreditorDocument: "12345678000190",
+			CreditorAccount:  OpenFinanceCreditorAccount{ISPB: "87654321", Number: "1234567", AccountType: "CACC"},
+		},
+		State:        testOpenFinanceState,
+		Nonce:        testOpenFinanceNonce,
+		CodeVerifier: "verifier-1",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ExpiresAt:    now.Add(time.Hour),
+	}
+	require.NoError(t, store.SaveConsent(ctx, consent))
+
+	return connector, store, consent
+}
+
+func TestOpenFinanceJWTSignAndVerifyPS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keySet := &openFinanceJWKS{Keys: []openFinanceJWK{openFinanceTestJWK(&key.PublicKey, "kid-1")}}
+
+	token, err := signOpenFinanceJWT(key, "kid-1", map[string]interface{}{"iss": "org-1", "aud": "org-2"})
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg":"PS256","typ":"JWT","kid":"kid-1"}`, string(headerJSON))
+
+	var claims openFinanceResponse
+	require.NoError(t, verifyOpenFinanceJWT(token, keySet, &claims))
+	assert.Equal(t, "org-1", claims.Iss)
+	assert.Equal(t, "org-2", claims.Aud)
+
+	t.Run("payload alterado", func(t *testing.T) {
+		tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"atacante"}`)) + "." + parts[2]
+		assert.ErrorIs(t, verifyOpenFinanceJWT(tampered, keySet, &claims), ErrOpenFinanceInvalidSignature)
+	})
+
+	t.Run("kid não publicado", func(t *testing.T) {
+		other := &openFinanceJWKS{Keys: []openFinanceJWK{openFinanceTestJWK(&key.PublicKey, "kid-2")}}
+		assert.ErrorIs(t, verifyOpenFinanceJWT(token, other, &claims), ErrOpenFinanceInvalidSignature)
+	})
+
+	t.Run("outra chave com o mesmo kid", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		other := &openFinanceJWKS{Keys: []openFinanceJWK{openFinanceTestJWK(&otherKey.PublicKey, "kid-1")}}
+		assert.ErrorIs(t, verifyOpenFinanceJWT(token, other, &claims), ErrOpenFinanceInvalidSignature)
+	})
+
+	t.Run("RS256 não é aceite", func(t *testing.T) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"kid-1"}`))
+		digest := sha256.Sum256([]byte(header + "." + parts[1]))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		rs256 := header + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(signature)
+		assert.ErrorIs(t, verifyOpenFinanceJWT(rs256, keySet, &claims), ErrOpenFinanceInvalidSignature)
+	})
+}
+
+func TestOpenFinanceHalfHash(t *testing.T) {
+	// Metade esquerda do SHA-256 de "abc" (ba7816bf8f01cfea414140de5dae2223)
+	assert.Equal(t, "ungWv48Bz-pBQUDeXa4iIw", openFinanceHalfHash("abc"))
+}
+
+func TestHandleRedirectInitiatesPayment(t *testing.T) {
+	bank := newFakeOpenFinanceBank(t)
+	connector, store, _ := newTestOpenFinanceConnector(t, bank)
+
+	consent, err := connector.HandleRedirect(context.Background(), testOpenFinanceState, testOpenFinanceCode,
+		bank.idToken(t, bank.key, nil), "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, OpenFinanceConsentConsumed, consent.Status)
+	assert.Equal(t, "pay-1", consent.PaymentID)
+	assert.Equal(t, OpenFinancePaymentReceived, consent.PaymentStatus)
+	assert.True(t, strings.HasPrefix(consent.EndToEndID, "E12345678"), consent.EndToEndID)
+
+	stored, err := store.GetConsent(context.Background(), "tenant-1", "consent-1")
+	require.NoError(t, err)
+	assert.Equal(t, OpenFinanceConsentConsumed, stored.Status)
+	assert.Empty(t, stored.State, "o state consumido não fica associado ao consentimento")
+
+	// O pedido de pagamento é um JWS PS256 do gateway dirigido à API da instituição
+	require.Len(t, bank.payments, 1)
+	request := bank.payments[0]
+	assert.Equal(t, testOpenFinanceGatewayOrg, request["iss"])
+	assert.Equal(t, bank.server.URL+openFinancePixPaymentsPath, request["aud"])
+	payments := request["data"].([]interface{})
+	require.Len(t, payments, 1)
+	assert.Equal(t, "consent-1", payments[0].(map[string]interface{})["consentId"])
+}
+
+func TestHandleRedirectConsumesStateOnce(t *testing.T) {
+	bank := newFakeOpenFinanceBank(t)
+	connector, _, _ := newTestOpenFinanceConnector(t, bank)
+	idToken := bank.idToken(t, bank.key, nil)
+
+	const redirects = 5
+	errs := make(chan error, redirects)
+	var wg sync.WaitGroup
+	for i := 0; i < redirects; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := connector.HandleRedirect(context.Background(), testOpenFinanceState, testOpenFinanceCode, idToken, "", "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrOpenFinanceInvalidState)
+	}
+	assert.Equal(t, 1, succeeded, "só um redirecionamento pode usar o state")
+
+	tokenCalls, payments := bank.counts()
+	assert.Equal(t, 1, tokenCalls, "o code só é trocado uma vez")
+	assert.Equal(t, 1, payments)
+}
+
+func TestHandleRedirectAuthorisationError(t *testing.T) {
+	bank := newFakeOpenFinanceBank(t)
+	connector, store, _ := newTestOpenFinanceConnector(t, bank)
+
+	consent, err := connector.HandleRedirect(context.Background(), testOpenFinanceState, "", "", "access_denied", "Titular recusou")
+	assert.ErrorIs(t, err, ErrOpenFinanceConsentRejected)
+	require.NotNil(t, consent)
+	assert.Equal(t, "access_denied Titular recusou", consent.RejectionReason)
+
+	stored, err := store.GetConsent(context.Background(), "tenant-1", "consent-1")
+	require.NoError(t, err)
+	assert.Equal(t, OpenFinanceConsentRejected, stored.Status)
+
+	_, err = connector.HandleRedirect(context.Background(), testOpenFinanceState, testOpenFinanceCode, bank.idToken(t, bank.key, nil), "", "")
+	assert.ErrorIs(t, err, ErrOpenFinanceInvalidState)
+}
+
+func TestHandleRedirectRejectsInvalidIDToken(t *testing.T) {
+	bank := newFakeOpenFinanceBank(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     *rsa.PrivateKey
+		claims  map[string]interface{}
+		missing bool
+		reason  string
+	}{
+		{name: "sem id_token", missing: true, reason: "invalid_request"},
+		{name: "assinado por outra chave", key: otherKey, reason: "invalid_id_token"},
+		{name: "emissor de outro servidor", claims: map[string]interface{}{"iss": "https://outro.example"}, reason: "invalid_id_token"},
+		{name: "audiência de outro cliente", claims: map[string]interface{}{"aud": "client-2"}, reason: "invalid_id_token"},
+		{name: "nonce divergente", claims: map[string]interface{}{"nonce": "nonce-2"}, reason: "invalid_id_token"},
+		{name: "expirado", claims: map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}, reason: "invalid_id_token"},
+		{name: "c_hash de outro code", claims: map[string]interface{}{"c_hash": openFinanceHalfHash("code-injetado")}, reason: "invalid_id_token"},
+		{name: "sem c_hash", claims: map[string]interface{}{"c_hash": nil}, reason: "invalid_id_token"},
+		{name: "s_hash de outro state", claims: map[string]interface{}{"s_hash": openFinanceHalfHash("state-2")}, reason: "invalid_id_token"},
+		{name: "sem s_hash", claims: map[string]interface{}{"s_hash": nil}, reason: "invalid_id_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, store, _ := newTestOpenFinanceConnector(t, bank)
+			tokenCallsBefore, paymentsBefore := bank.counts()
+
+			idToken := ""
+			if !tt.missing {
+				key := tt.key
+				if key == nil {
+					key = bank.key
+				}
+				idToken = bank.idToken(t, key, tt.claims)
+			}
+
+			consent, err := connector.HandleRedirect(context.Background(), testOpenFinanceState, testOpenFinanceCode, idToken, "", "")
+			assert.ErrorIs(t, err, ErrOpenFinanceConsentRejected)
+			require.NotNil(t, consent)
+			assert.Equal(t, OpenFinanceConsentRejected, consent.Status)
+
+			stored, err := store.GetConsent(context.Background(), "tenant-1", "consent-1")
+			require.NoError(t, err)
+			assert.Equal(t, OpenFinanceConsentRejected, stored.Status)
+			assert.Contains(t, stored.RejectionReason, tt.reason)
+
+			tokenCalls, payments := bank.counts()
+			assert.Equal(t, tokenCallsBefore, tokenCalls, "o code não é trocado sem um id_token válido")
+			assert.Equal(t, paymentsBefore, payments)
+		})
+	}
+}
+
+func TestSendSignedRequiresIssuerAndAudience(t *testing.T) {
+	bank := newFakeOpenFinanceBank(t)
+
+	tests := []struct {
+		name   string
+		adjust func(claims map[string]interface{})
+	}{
+		{name: "sem emissor", adjust: func(claims map[string]interface{}) { delete(claims, "iss") }},
+		{name: "sem audiência", adjust: func(claims map[string]interface{}) { delete(claims, "aud") }},
+		{name: "emissor de outro participante", adjust: func(claims map[string]interface{}) { claims["iss"] = "other-bank" }},
+		{name: "audiência de outro iniciador", adjust: func(claims map[string]interface{}) { claims["aud"] = "other-gateway" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank.mu.Lock()
+			bank.responseClaims = tt.adjust
+			bank.mu.Unlock()
+			t.Cleanup(func() {
+				bank.mu.Lock()
+				bank.responseClaims = nil
+				bank.mu.Unlock()
+			})
+
+			connector, store, _ := newTestOpenFinanceConnector(t, bank)
+			_, err := connector.HandleRedirect(context.Background(), testOpenFinanceState, testOpenFinanceCode,
+				bank.idToken(t, bank.key, nil), "", "")
+			assert.ErrorIs(t, err, ErrOpenFinanceInvalidSignature)
+
+			stored, err := store.GetConsent(context.Background(), "tenant-1", "consent-1")
+			require.NoError(t, err)
+			assert.NotEqual(t, OpenFinanceConsentConsumed, stored.Status)
+			assert.Empty(t, stored.PaymentID, "o pagamento da resposta rejeitada não é registado")
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// openFinanceDiscovery contém os campos usados do documento OpenID Connect Discovery do participante
+type openFinanceDiscovery struct {
+	Issuer                     string `json:"issuer"`
+	AuthorizationEndpoint      string `json:"authorization_endpoint"`
+	TokenEndpoint              string `json:"token_endpoint"`
+	PushedAuthorizationRequest string `json:"pushed_authorization_request_endpoint"`
+	RegistrationEndpoint       string `json:"registration_endpoint"`
+	JWKSURI                    string `json:"jwks_uri"`
+	MTLSEndpointAliases        struct {
+		TokenEndpoint              string `json:"token_endpoint"`
+		PushedAuthorizationRequest string `json:"pushed_authorization_request_endpoint"`
+		RegistrationEndpoint       string `json:"registration_endpoint"`
+	} `json:"mtls_endpoint_aliases"`
+}
+
+// tokenEndpoint retorna o endpoint de token com mTLS, preferindo o alias quando publicado
+func (d *openFinanceDiscovery) tokenEndpoint() string {
+	if d.MTLSEndpointAliases.TokenEndpoint != "" {
+		return d.MTLSEndpointAliases.TokenEndpoint
+	}
+	return d.TokenEndpoint
+}
+
+// parEndpoint retorna o endpoint de Pushed Authorization Request com mTLS
+func (d *openFinanceDiscovery) parEndpoint() string {
+	if d.MTLSEndpointAliases.PushedAuthorizationRequest != "" {
+		return d.MTLSEndpointAliases.PushedAuthorizationRequest
+	}
+	return d.PushedAuthorizationRequest
+}
+
+// registrationEndpoint retorna o endpoint de registo dinâmico com mTLS
+func (d *openFinanceDiscovery) registrationEndpoint() string {
+	if d.MTLSEndpointAliases.RegistrationEndpoint != "" {
+		return d.MTLSEndpointAliases.RegistrationEndpoint
+	}
+	return d.RegistrationEndpoint
+}
+
+// openFinanceDirectoryEntry é a representação de uma organização no endpoint de participantes do diretório
+type openFinanceDirectoryEntry struct {
+	OrganisationID       string `json:"OrganisationId"`
+	OrganisationName     string `json:"OrganisationName"`
+	AuthorisationServers []struct {
+		OpenIDDiscoveryDocument string `json:"OpenIDDiscoveryDocument"`
+		ApiResources            []struct {
+			ApiFamilyType         string `json:"ApiFamilyType"`
+			ApiDiscoveryEndpoints []struct {
+				ApiEndpoint string `json:"ApiEndpoint"`
+			} `json:"ApiDiscoveryEndpoints"`
+		} `json:"ApiResources"`
+	} `json:"AuthorisationServers"`
+}
+
+// openFinanceRegistrationResponse representa a resposta do registo dinâmico (RFC 7591/7592)
+type openFinanceRegistrationResponse struct {
+	ClientID                string `json:"client_id"`
+	RegistrationAccessToken string `json:"registration_access_token"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+}
+
+// participant resolve o participante no diretório, reutilizando a lista em cache dentro do TTL
+func (c *OpenFinanceConnector) participant(ctx context.Context, organisationID string) (*OpenFinanceParticipant, error) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if time.Since(c.participantsFetchedAt) > c.config.ParticipantsCacheTTL {
+		participants, err := c.fetchParticipants(ctx)
+		if err != nil {
+			// Uma lista anterior continua válida se o diretório estiver indisponível
+			if c.participants == nil {
+				return nil, err
+			}
+			c.logger.WarnWithContext(ctx, "Falha ao atualizar participantes do diretório Open Finance",
+				"error", err.Error())
+		} else {
+			c.participants = participants
+			c.participantsFetchedAt = time.Now()
+		}
+	}
+
+	participant, ok := c.participants[organisationID]
+	if !ok {
+		return nil, ErrOpenFinanceParticipantNotFound
+	}
+	return participant, nil
+}
+
+// fetchParticipants obtém do diretório as organizações que publicam a API de pagamentos PIX
+func (c *OpenFinanceConnector) fetchParticipants(ctx context.Context) (map[string]*OpenFinanceParticipant, error) {
+	var entries []openFinanceDirectoryEntry
+	if err := c.getJSON(ctx, c.config.DirectoryParticipantsURL, "", &entries); err != nil {
+		return nil, fmt.Errorf("falha ao consultar participantes do diretório: %w", err)
+	}
+
+	participants := make(map[string]*OpenFinanceParticipant)
+	for _, entry := range entries {
+		for _, server := range entry.AuthorisationServers {
+			for _, resource := range server.ApiResources {
+				if resource.ApiFamilyType != openFinancePaymentsAPIFamily || len(resource.ApiDiscoveryEndpoints) == 0 {
+					continue
+				}
+				// O endpoint publicado inclui o caminho do recurso; guarda-se apenas a base da API
+				endpoint := resource.ApiDiscoveryEndpoints[0].ApiEndpoint
+				if i := strings.Index(endpoint, "/open-banking/"); i > 0 {
+					endpoint = endpoint[:i]
+				}
+				participants[entry.OrganisationID] = &OpenFinanceParticipant{
+					OrganisationID:   entry.OrganisationID,
+					OrganisationName: entry.OrganisationName,
+					DiscoveryURL:     server.OpenIDDiscoveryDocument,
+					PaymentsAPIURL:   strings.TrimRight(endpoint, "/"),
+				}
+			}
+		}
+	}
+
+	return participants, nil
+}
+
+// discovery obtém o documento OpenID Connect Discovery do participante
+func (c *OpenFinanceConnector) discovery(ctx context.Context, participant *OpenFinanceParticipant) (*openFinanceDiscovery, error) {
+	c.cacheMutex.Lock()
+	cached, ok := c.discoveries[participant.OrganisationID]
+	c.cacheMutex.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var doc openFinanceDiscovery
+	if err := c.getJSON(ctx, participant.DiscoveryURL, "", &doc); err != nil {
+		return nil, fmt.Errorf("falha ao obter discovery do participante %s: %w", participant.OrganisationID, err)
+	}
+
+	c.cacheMutex.Lock()
+	c.discoveries[participant.OrganisationID] = &doc
+	c.cacheMutex.Unlock()
+
+	return &doc, nil
+}
+
+// jwks obtém as chaves públicas do participante; refresh ignora a cache após falha de validação
+func (c *OpenFinanceConnector) jwks(ctx context.Context, participant *OpenFinanceParticipant, refresh bool) (*openFinanceJWKS, error) {
+	c.cacheMutex.Lock()
+	cached, ok := c.keySets[participant.OrganisationID]
+	c.cacheMutex.Unlock()
+	if ok && !refresh {
+		return cached, nil
+	}
+
+	doc, err := c.discovery(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet openFinanceJWKS
+	if err := c.getJSON(ctx, doc.JWKSURI, "", &keySet); err != nil {
+		return nil, fmt.Errorf("falha ao obter JWKS do participante %s: %w", participant.OrganisationID, err)
+	}
+
+	c.cacheMutex.Lock()
+	c.keySets[participant.OrganisationID] = &keySet
+	c.cacheMutex.Unlock()
+
+	return &keySet, nil
+}
+
+// ensureRegistration retorna o registo DCR do participante, registando o gateway quando ainda não existe
+func (c *OpenFinanceConnector) ensureRegistration(ctx context.Context, participant *OpenFinanceParticipant) (*OpenFinanceClientRegistration, error) {
+	registration, err := c.store.GetRegistration(ctx, participant.OrganisationID)
+	if err == nil {
+		return registration, nil
+	}
+	if !errors.Is(err, ErrOpenFinanceRegistrationNotFound) {
+		return nil, err
+	}
+
+	c.registerMutex.Lock()
+	defer c.registerMutex.Unlock()
+
+	// Outro pedido pode ter concluído o registo enquanto se aguardava
+	if registration, err := c.store.GetRegistration(ctx, participant.OrganisationID); err == nil {
+		return registration, nil
+	}
+
+	return c.register(ctx, participant)
+}
+
+// register efetua o registo dinâmico do gateway no servidor de autorização do participante
+func (c *OpenFinanceConnector) register(ctx context.Context, participant *OpenFinanceParticipant) (*OpenFinanceClientRegistration, error) {
+	ctx, span := c.tracer.StartSpan(ctx, "OpenFinanceConnector.register")
+	defer span.End()
+
+	doc, err := c.discovery(ctx, participant)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := c.registrationMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp openFinanceRegistrationResponse
+	if err := c.sendJSON(ctx, http.MethodPost, doc.registrationEndpoint(), "", metadata, &resp); err != nil {
+		c.metricsRecorder.CounterInc("payment_open_finance_registrations_total", map[string]string{
+			"organisation_id": participant.OrganisationID,
+			"result":          "failed",
+		})
+		return nil, fmt.Errorf("falha no registo dinâmico junto de %s: %w", participant.OrganisationID, err)
+	}
+
+	now := time.Now()
+	registration := &OpenFinanceClientRegistration{
+		OrganisationID:          participant.OrganisationID,
+		ClientID:                resp.ClientID,
+		RegistrationAccessToken: resp.RegistrationAccessToken,
+		RegistrationClientURI:   resp.RegistrationClientURI,
+		SubjectDN:               c.certificates.SubjectDN(),
+		CertificateFingerprint:  c.certificates.Fingerprint(),
+		RegisteredAt:            now,
+		UpdatedAt:               now,
+	}
+	if err := c.store.SaveRegistration(ctx, registration); err != nil {
+		return nil, fmt.Errorf("falha ao gravar registo dinâmico: %w", err)
+	}
+
+	c.metricsRecorder.CounterInc("payment_open_finance_registrations_total", map[string]string{
+		"organisation_id": participant.OrganisationID,
+		"result":          "registered",
+	})
+	c.logger.InfoWithContext(ctx, "Gateway registado no participante Open Finance",
+		"organisation_id", participant.OrganisationID,
+		"client_id", registration.ClientID)
+
+	return registration, nil
+}
+
+// UpdateRegistrations atualiza os registos DCR cujo certificado difere do certificado de transporte atual
+// É chamado após a rotação do certificado, pois o tls_client_auth_subject_dn e o fingerprint mudam
+func (c *OpenFinanceConnector) UpdateRegistrations(ctx context.Context) {
+	ctx, span := c.tracer.StartSpan(ctx, "OpenFinanceConnector.UpdateRegistrations")
+	defer span.End()
+
+	registrations, err := c.store.ListRegistrations(ctx)
+	if err != nil {
+		c.logger.ErrorWithContext(ctx, "Falha ao listar registos Open Finance", "error", err.Error())
+		return
+	}
+
+	fingerprint := c.certificates.Fingerprint()
+	for _, registration := range registrations {
+		if registration.CertificateFingerprint == fingerprint {
+			continue
+		}
+
+		result := "updated"
+		if err := c.updateRegistration(ctx, registration); err != nil {
+			result = "failed"
+			c.logger.ErrorWithContext(ctx, "Falha ao atualizar registo Open Finance após rotação do certificado",
+				"organisation_id", registration.OrganisationID,
+				"client_id", registration.ClientID,
+				"error", err.Error())
+		}
+		c.metricsRecorder.CounterInc("payment_open_finance_registrations_total", map[string]string{
+			"organisation_id": registration.OrganisationID,
+			"result":          result,
+		})
+	}
+}
+
+// updateRegistration atualiza o registo do cliente (RFC 7592) com os dados do certificado atual
+func (c *OpenFinanceConnector) updateRegistration(ctx context.Context, registration *OpenFinanceClientRegistration) error {
+	metadata, err := c.registrationMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	metadata["client_id"] = registration.ClientID
+
+	var resp openFinanceRegistrationResponse
+	if err := c.sendJSON(ctx, http.MethodPut, registration.RegistrationClientURI, registration.RegistrationAccessToken, metadata, &resp); err != nil {
+		return err
+	}
+
+	// O servidor pode emitir um novo registration_access_token a cada atualização
+	if resp.RegistrationAccessToken != "" {
+		registration.RegistrationAccessToken = resp.RegistrationAccessToken
+	}
+	registration.SubjectDN = c.certificates.SubjectDN()
+	registration.CertificateFingerprint = c.certificates.Fingerprint()
+	registration.UpdatedAt = time.Now()
+
+	return c.store.SaveRegistration(ctx, registration)
+}
+
+// registrationMetadata monta os metadados do cliente com o software statement assinado pelo diretório
+func (c *OpenFinanceConnector) registrationMetadata(ctx context.Context) (map[string]interface{}, error) {
+	ssa, err := c.softwareStatement(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"software_statement":                    ssa,
+		"grant_types":                           []string{"authorization_code", "implicit", "refresh_token", "client_credentials"},
+		"response_types":                        []string{"code id_token"},
+		"redirect_uris":                         []string{c.config.RedirectURI},
+		"jwks_uri":                              c.config.SoftwareJWKSURI,
+		"token_endpoint_auth_method":            "tls_client_auth",
+		"tls_client_auth_subject_dn":            c.certificates.SubjectDN(),
+		"id_token_signed_response_alg":          "PS256",
+		"request_object_signing_alg":            "PS256",
+		"require_pushed_authorization_requests": true,
+		"scope":                                 "openid payments",
+	}, nil
+}
+
+// softwareStatement obtém do diretório a asserção (SSA) do software do gateway
+func (c *OpenFinanceConnector) softwareStatement(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {c.config.DirectoryClientID},
+		"scope":      {"directory:software"},
+	}
+	token, err := c.requestToken(ctx, c.config.DirectoryTokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("falha ao autenticar no diretório: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.SoftwareStatementURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/jwt")
+
+	body, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("falha ao obter software statement: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// getJSON executa um GET e decodifica a resposta JSON
+func (c *OpenFinanceConnector) getJSON(ctx context.Context, endpoint, bearer string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// sendJSON envia um corpo JSON e decodifica a resposta JSON
+func (c *OpenFinanceConnector) sendJSON(ctx context.Context, method, endpoint, bearer string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// do executa a requisição com mTLS segundo a política de resiliência e retorna o corpo
+// das respostas 2xx; cada tentativa usa uma cópia da requisição com o corpo recriado
+func (c *OpenFinanceConnector) do(req *http.Request) ([]byte, error) {
+	resp, err := resilience.DoHTTP(req.Context(), c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		return attempt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s retornou status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return body, nil
+}
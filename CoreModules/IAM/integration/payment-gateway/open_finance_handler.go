@@ -0,0 +1,106 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenFinanceHandler expõe a API HTTP de iniciação de pagamentos Open Finance Brasil
+type OpenFinanceHandler struct {
+	connector *OpenFinanceConnector
+}
+
+// NewOpenFinanceHandler cria uma nova instância do OpenFinanceHandler
+func NewOpenFinanceHandler(connector *OpenFinanceConnector) *OpenFinanceHandler {
+	return &OpenFinanceHandler{connector: connector}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *OpenFinanceHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/open-finance/payments", h.InitiatePayment).Methods(http.MethodPost)
+	router.HandleFunc("/open-finance/payments/{consentId}", h.GetPayment).Methods(http.MethodGet)
+	router.HandleFunc("/open-finance/callback", h.HandleRedirect).Methods(http.MethodGet, http.MethodPost)
+}
+
+// InitiatePayment cria o consentimento e retorna a URL para o titular autorizar o pagamento
+func (h *OpenFinanceHandler) InitiatePayment(w http.ResponseWriter, r *http.Request) {
+	var req OpenFinancePaymentInitiationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	consent, err := h.connector.CreatePaymentConsent(r.Context(), &req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, consent)
+}
+
+// GetPayment retorna o consentimento e o status do pagamento
+func (h *OpenFinanceHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	consent, err := h.connector.GetPaymentConsent(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["consentId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, consent)
+}
+
+// HandleRedirect trata o retorno do titular após a autorização na instituição detentora
+// Aceita os parâmetros na query ou num formulário enviado pela página de retorno
+func (h *OpenFinanceHandler) HandleRedirect(w http.ResponseWriter, r *http.Request) {
+	consent, err := h.connector.HandleRedirect(r.Context(),
+		r.FormValue("state"),
+		r.FormValue("code"),
+		r.FormValue("id_token"),
+		r.FormValue("error"),
+		r.FormValue("error_description"))
+	if err != nil && !(errors.Is(err, ErrOpenFinanceConsentRejected) && consent != nil) {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	// Devolve o titular à loja do comerciante quando indicada no pedido
+	if returnURL, parseErr := url.Parse(consent.Request.ReturnURL); consent.Request.ReturnURL != "" && parseErr == nil {
+		query := returnURL.Query()
+		query.Set("consent_id", consent.ConsentID)
+		query.Set("transaction_id", consent.TransactionID)
+		query.Set("status", string(consent.Status))
+		returnURL.RawQuery = query.Encode()
+		http.Redirect(w, r, returnURL.String(), http.StatusFound)
+		return
+	}
+
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, consent)
+}
+
+// respondWithServiceError converte erros do conector em respostas HTTP
+func (h *OpenFinanceHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrOpenFinanceConsentNotFound):
+		respondWithError(w, http.StatusNotFound, "consent_not_found", err.Error())
+	case errors.Is(err, ErrOpenFinanceParticipantNotFound):
+		respondWithError(w, http.StatusNotFound, "participant_not_found", err.Error())
+	case errors.Is(err, ErrOpenFinanceInvalidState):
+		respondWithError(w, http.StatusBadRequest, "invalid_state", err.Error())
+	case errors.Is(err, ErrOpenFinanceConsentRejected):
+		respondWithError(w, http.StatusUnprocessableEntity, "consent_rejected", err.Error())
+	case errors.Is(err, ErrOpenFinanceInvalidSignature), errors.Is(err, ErrOpenFinanceCertificateUnavailable):
+		respondWithError(w, http.StatusBadGateway, "open_finance_unavailable", err.Error())
+	default:
+		respondWithError(w, http.StatusBadRequest, "open_finance_error", err.Error())
+	}
+}
@@ -0,0 +1,128 @@
+package paymentgateway
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// openFinanceJWK representa uma chave RSA publicada no JWKS de um participante
+type openFinanceJWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// openFinanceJWKS representa o documento JWKS de um participante
+type openFinanceJWKS struct {
+	Keys []openFinanceJWK `json:"keys"`
+}
+
+// publicKey converte a JWK numa chave pública RSA
+func (k openFinanceJWK) publicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("tipo de chave não suportado: %s", k.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("módulo da chave inválido: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("expoente da chave inválido: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// openFinanceHalfHash calcula c_hash e s_hash do id_token: a metade esquerda do SHA-256 do valor, em base64url
+func openFinanceHalfHash(value string) string {
+	digest := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(digest[:len(digest)/2])
+}
+
+// signOpenFinanceJWT assina as claims em PS256, o algoritmo exigido pelo perfil FAPI do Open Finance Brasil
+func signOpenFinanceJWT(key *rsa.PrivateKey, kid string, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "PS256", "typ": "JWT", "kid": kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	if err != nil {
+		return "", fmt.Errorf("falha ao assinar mensagem: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyOpenFinanceJWT valida a assinatura PS256 de uma resposta com as chaves do participante e decodifica as claims
+func verifyOpenFinanceJWT(token string, jwks *openFinanceJWKS, claims interface{}) error {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: formato compacto inválido", ErrOpenFinanceInvalidSignature)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: cabeçalho inválido", ErrOpenFinanceInvalidSignature)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return fmt.Errorf("%w: cabeçalho inválido", ErrOpenFinanceInvalidSignature)
+	}
+	if header.Alg != "PS256" {
+		return fmt.Errorf("%w: algoritmo %s não permitido", ErrOpenFinanceInvalidSignature, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: assinatura mal codificada", ErrOpenFinanceInvalidSignature)
+	}
+
+	var publicKey *rsa.PublicKey
+	for _, jwk := range jwks.Keys {
+		if jwk.Kid == header.Kid && (jwk.Use == "" || jwk.Use == "sig") {
+			if publicKey, err = jwk.publicKey(); err != nil {
+				return fmt.Errorf("%w: %v", ErrOpenFinanceInvalidSignature, err)
+			}
+			break
+		}
+	}
+	if publicKey == nil {
+		return fmt.Errorf("%w: kid %s não publicado pelo participante", ErrOpenFinanceInvalidSignature, header.Kid)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPSS(publicKey, crypto.SHA256, digest[:], signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}); err != nil {
+		return fmt.Errorf("%w: %v", ErrOpenFinanceInvalidSignature, err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: payload mal codificado", ErrOpenFinanceInvalidSignature)
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return fmt.Errorf("falha ao decodificar claims da resposta: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// OpenFinanceConsentStatus representa o estado de um consentimento de pagamento (API Pagamentos v4 BCB)
+type OpenFinanceConsentStatus string
+
+const (
+	// Status de consentimento definidos pela especificação Open Finance Brasil
+	OpenFinanceConsentAwaitingAuthorisation OpenFinanceConsentStatus = "AWAITING_AUTHORISATION"
+	OpenFinanceConsentAuthorised            OpenFinanceConsentStatus = "AUTHORISED"
+	OpenFinanceConsentRejected              OpenFinanceConsentStatus = "REJECTED"
+	OpenFinanceConsentConsumed              OpenFinanceConsentStatus = "CONSUMED"
+)
+
+// OpenFinancePaymentStatus representa o estado de uma iniciação de pagamento PIX (ISO 20022)
+type OpenFinancePaymentStatus string
+
+const (
+	OpenFinancePaymentReceived        OpenFinancePaymentStatus = "RCVD"
+	OpenFinancePaymentAccepted        OpenFinancePaymentStatus = "ACCP"
+	OpenFinancePaymentAcceptedProfile OpenFinancePaymentStatus = "ACPD"
+	OpenFinancePaymentSettled         OpenFinancePaymentStatus = "ACSC"
+	OpenFinancePaymentPending         OpenFinancePaymentStatus = "PDNG"
+	OpenFinancePaymentScheduled       OpenFinancePaymentStatus = "SCHD"
+	OpenFinancePaymentRejected        OpenFinancePaymentStatus = "RJCT"
+	OpenFinancePaymentCancelled       OpenFinancePaymentStatus = "CANC"
+)
+
+// Recursos da API de Pagamentos publicados pelas instituições detentoras
+const (
+	openFinancePaymentsAPIFamily = "payments-pix"
+	openFinanceConsentsPath      = "/open-banking/payments/v4/consents"
+	openFinancePixPaymentsPath   = "/open-banking/payments/v4/pix/payments"
+)
+
+// IsFinal verifica se o status do pagamento é terminal e dispensa novas consultas
+func (s OpenFinancePaymentStatus) IsFinal() bool {
+	return s == OpenFinancePaymentSettled || s == OpenFinancePaymentRejected || s == OpenFinancePaymentCancelled
+}
+
+// Erros do conector Open Finance
+var (
+	ErrOpenFinanceParticipantNotFound    = errors.New("participante Open Finance não encontrado no diretório")
+	ErrOpenFinanceConsentNotFound        = errors.New("consentimento Open Finance não encontrado")
+	ErrOpenFinanceInvalidState           = errors.New("parâmetro state inválido ou expirado")
+	ErrOpenFinanceConsentRejected        = errors.New("consentimento rejeitado pelo titular ou pela instituição detentora")
+	ErrOpenFinanceRegistrationNotFound   = errors.New("registo de cliente (DCR) não encontrado para o participante")
+	ErrOpenFinanceCertificateUnavailable = errors.New("certificado Open Finance indisponível")
+	ErrOpenFinanceInvalidSignature       = errors.New("assinatura JWS da resposta Open Finance inválida")
+	ErrOpenFinanceInvalidIDToken         = errors.New("id_token do redirecionamento Open Finance inválido")
+)
+
+// OpenFinanceParticipant representa uma instituição detentora de conta publicada no diretório
+type OpenFinanceParticipant struct {
+	OrganisationID   string `json:"organisation_id"`
+	OrganisationName string `json:"organisation_name"`
+	DiscoveryURL     string `json:"discovery_url"`
+	PaymentsAPIURL   string `json:"payments_api_url"`
+}
+
+// OpenFinanceClientRegistration representa o registo dinâmico do gateway junto de um participante
+type OpenFinanceClientRegistration struct {
+	OrganisationID          string    `json:"organisation_id"`
+	ClientID                string    `json:"client_id"`
+	RegistrationAccessToken string    `json:"-"`
+	RegistrationClientURI   string    `json:"registration_client_uri"`
+	SubjectDN               string    `json:"subject_dn"`
+	CertificateFingerprint  string    `json:"certificate_fingerprint"`
+	RegisteredAt            time.Time `json:"registered_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// OpenFinanceCreditorAccount identifica a conta do recebedor (comerciante)
+type OpenFinanceCreditorAccount struct {
+	ISPB        string `json:"ispb"`
+	Issuer      string `json:"issuer,omitempty"`
+	Number      string `json:"number"`
+	AccountType string `json:"accountType"`
+}
+
+// OpenFinancePaymentInitiationRequest representa o pedido de iniciação de um pagamento PIX via Open Finance
+type OpenFinancePaymentInitiationRequest struct {
+	TenantID         string                     `json:"tenant_id"`
+	MerchantID       string                     `json:"merchant_id"`
+	TransactionID    string                     `json:"transaction_id"`
+	OrganisationID   string                     `json:"organisation_id"`
+	Amount           float64                    `json:"amount"`
+	DebtorDocument   string                     `json:"debtor_document"`
+	CreditorName     string                     `json:"creditor_name"`
+	CreditorDocument string                     `json:"creditor_document"`
+	CreditorAccount  OpenFinanceCreditorAccount `json:"creditor_account"`
+	ProxyKey         string                     `json:"proxy_key,omitempty"`
+	Remittance       string                     `json:"remittance_information,omitempty"`
+	ReturnURL        string                     `json:"return_url,omitempty"`
+}
+
+// OpenFinancePaymentConsent acompanha o consentimento e o pagamento iniciado a partir dele
+type OpenFinancePaymentConsent struct {
+	ConsentID        string                              `json:"consent_id"`
+	TenantID         string                              `json:"tenant_id"`
+	MerchantID       string                              `json:"merchant_id"`
+	TransactionID    string                              `json:"transaction_id"`
+	OrganisationID   string                              `json:"organisation_id"`
+	Status           OpenFinanceConsentStatus            `json:"status"`
+	AuthorizationURL string                              `json:"authorization_url,omitempty"`
+	PaymentID        string                              `json:"payment_id,omitempty"`
+	EndToEndID       string                              `json:"end_to_end_id,omitempty"`
+	PaymentStatus    OpenFinancePaymentStatus            `json:"payment_status,omitempty"`
+	RejectionReason  string                              `json:"rejection_reason,omitempty"`
+	Request          OpenFinancePaymentInitiationRequest `json:"-"`
+	State            string                              `json:"-"`
+	Nonce            string                              `json:"-"`
+	CodeVerifier     string                              `json:"-"`
+	CreatedAt        time.Time                           `json:"created_at"`
+	UpdatedAt        time.Time                           `json:"updated_at"`
+	ExpiresAt        time.Time                           `json:"expires_at"`
+}
@@ -0,0 +1,144 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// OpenFinanceStore define a persistência dos registos DCR e dos consentimentos Open Finance
+type OpenFinanceStore interface {
+	// SaveRegistration grava o registo de cliente junto de um participante
+	SaveRegistration(ctx context.Context, registration *OpenFinanceClientRegistration) error
+
+	// GetRegistration recupera o registo de cliente de um participante
+	GetRegistration(ctx context.Context, organisationID string) (*OpenFinanceClientRegistration, error)
+
+	// ListRegistrations lista todos os registos de cliente
+	ListRegistrations(ctx context.Context) ([]*OpenFinanceClientRegistration, error)
+
+	// SaveConsent grava o consentimento e o estado do pagamento associado
+	SaveConsent(ctx context.Context, consent *OpenFinancePaymentConsent) error
+
+	// GetConsent recupera um consentimento do tenant
+	GetConsent(ctx context.Context, tenantID, consentID string) (*OpenFinancePaymentConsent, error)
+
+	// GetConsentByState recupera o consentimento pelo parâmetro state do redirecionamento
+	GetConsentByState(ctx context.Context, state string) (*OpenFinancePaymentConsent, error)
+
+	// ConsumeState invalida o state e retorna o consentimento que aguarda autorização.
+	// A operação é atómica: entre redirecionamentos concorrentes com o mesmo state só um a obtém
+	ConsumeState(ctx context.Context, state string) (*OpenFinancePaymentConsent, error)
+}
+
+// InMemoryOpenFinanceStore armazena registos e consentimentos Open Finance em memória
+type InMemoryOpenFinanceStore struct {
+	registrations map[string]*OpenFinanceClientRegistration
+	consents      map[string]*OpenFinancePaymentConsent
+	states        map[string]string
+	mutex         sync.RWMutex
+}
+
+// NewInMemoryOpenFinanceStore cria um novo armazenamento em memória
+func NewInMemoryOpenFinanceStore() *InMemoryOpenFinanceStore {
+	return &InMemoryOpenFinanceStore{
+		registrations: make(map[string]*OpenFinanceClientRegistration),
+		consents:      make(map[string]*OpenFinancePaymentConsent),
+		states:        make(map[string]string),
+	}
+}
+
+// SaveRegistration grava uma cópia do registo de cliente
+func (s *InMemoryOpenFinanceStore) SaveRegistration(ctx context.Context, registration *OpenFinanceClientRegistration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *registration
+	s.registrations[registration.OrganisationID] = &copied
+	return nil
+}
+
+// GetRegistration retorna o registo de cliente do participante
+func (s *InMemoryOpenFinanceStore) GetRegistration(ctx context.Context, organisationID string) (*OpenFinanceClientRegistration, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	registration, ok := s.registrations[organisationID]
+	if !ok {
+		return nil, ErrOpenFinanceRegistrationNotFound
+	}
+	copied := *registration
+	return &copied, nil
+}
+
+// ListRegistrations retorna cópias de todos os registos de cliente
+func (s *InMemoryOpenFinanceStore) ListRegistrations(ctx context.Context) ([]*OpenFinanceClientRegistration, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	registrations := make([]*OpenFinanceClientRegistration, 0, len(s.registrations))
+	for _, registration := range s.registrations {
+		copied := *registration
+		registrations = append(registrations, &copied)
+	}
+	return registrations, nil
+}
+
+// SaveConsent grava uma cópia do consentimento
+func (s *InMemoryOpenFinanceStore) SaveConsent(ctx context.Context, consent *OpenFinancePaymentConsent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *consent
+	s.consents[consent.TenantID+"/"+consent.ConsentID] = &copied
+	if consent.State != "" {
+		s.states[consent.State] = consent.TenantID + "/" + consent.ConsentID
+	}
+	return nil
+}
+
+// GetConsent retorna o consentimento do tenant
+func (s *InMemoryOpenFinanceStore) GetConsent(ctx context.Context, tenantID, consentID string) (*OpenFinancePaymentConsent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	consent, ok := s.consents[tenantID+"/"+consentID]
+	if !ok {
+		return nil, ErrOpenFinanceConsentNotFound
+	}
+	copied := *consent
+	return &copied, nil
+}
+
+// GetConsentByState retorna o consentimento associado ao state do redirecionamento
+func (s *InMemoryOpenFinanceStore) GetConsentByState(ctx context.Context, state string) (*OpenFinancePaymentConsent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	consent, ok := s.consents[s.states[state]]
+	if !ok {
+		return nil, ErrOpenFinanceInvalidState
+	}
+	copied := *consent
+	return &copied, nil
+}
+
+// ConsumeState remove o state e retorna o consentimento, se ainda aguardar autorização
+func (s *InMemoryOpenFinanceStore) ConsumeState(ctx context.Context, state string) (*OpenFinancePaymentConsent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, ok := s.states[state]
+	if !ok {
+		return nil, ErrOpenFinanceInvalidState
+	}
+	delete(s.states, state)
+
+	consent, ok := s.consents[key]
+	if !ok || consent.Status != OpenFinanceConsentAwaitingAuthorisation {
+		return nil, ErrOpenFinanceInvalidState
+	}
+	// Sem o state, gravações posteriores do consentimento não voltam a associá-lo
+	consent.State = ""
+	copied := *consent
+	return &copied, nil
+}
@@ -0,0 +1,84 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// PaymentConsentClient confirma no serviço de consentimentos do IAM o consentimento do cliente
+type PaymentConsentClient interface {
+	// HasConsent indica se o usuário tem um consentimento ativo para a finalidade; falhas de rede e
+	// respostas inválidas retornam ErrPaymentConsentUnavailable
+	HasConsent(ctx context.Context, tenantID, userID, purpose string) (bool, error)
+}
+
+// paymentConsentResponse é a resposta da verificação de consentimento
+type paymentConsentResponse struct {
+	Granted bool `json:"granted"`
+}
+
+// HTTPPaymentConsentClient consulta a API de consentimentos do IAM por HTTP/JSON
+type HTTPPaymentConsentClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPPaymentConsentClient cria o cliente da API de consentimentos
+func NewHTTPPaymentConsentClient(config ScheduledPaymentConfig) *HTTPPaymentConsentClient {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultScheduledPaymentConsentTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPPaymentConsentClient{
+		baseURL:    strings.TrimRight(config.ConsentServiceURL, "/"),
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// HasConsent consulta GET /api/v1/consents/check; 404 indica que o usuário não tem consentimento
+func (c *HTTPPaymentConsentClient) HasConsent(ctx context.Context, tenantID, userID, purpose string) (bool, error) {
+	target := c.baseURL + "/api/v1/consents/check?" + url.Values{"user_id": {userID}, "purpose": {purpose}}.Encode()
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Tenant-ID", tenantID)
+		if c.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrPaymentConsentUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("%w: serviço retornou status %d", ErrPaymentConsentUnavailable, resp.StatusCode)
+	}
+
+	var consent paymentConsentResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&consent); err != nil {
+		return false, fmt.Errorf("%w: resposta inválida: %v", ErrPaymentConsentUnavailable, err)
+	}
+	return consent.Granted, nil
+}
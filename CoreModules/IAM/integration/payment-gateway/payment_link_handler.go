@@ -0,0 +1,154 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// PaymentLinkHandler expõe às equipas de suporte a criação, a consulta e o cancelamento dos links de pagamento
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type PaymentLinkHandler struct {
+	service *PaymentLinkService
+}
+
+// NewPaymentLinkHandler cria uma nova instância do PaymentLinkHandler
+func NewPaymentLinkHandler(service *PaymentLinkService) *PaymentLinkHandler {
+	return &PaymentLinkHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *PaymentLinkHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/payment-links", h.List).Methods(http.MethodGet)
+	router.HandleFunc("/support/payment-links", h.Create).Methods(http.MethodPost)
+	router.HandleFunc("/support/payment-links/{linkId}", h.Get).Methods(http.MethodGet)
+	router.HandleFunc("/support/payment-links/{linkId}/cancel", h.Cancel).Methods(http.MethodPost)
+}
+
+// List lista os links de pagamento do tenant filtrados por comerciante e status
+func (h *PaymentLinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	links, err := h.service.List(r.Context(), PaymentLinkFilter{
+		TenantID:   r.Header.Get("X-Tenant-ID"),
+		MerchantID: r.URL.Query().Get("merchant_id"),
+		Status:     r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar links de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, links)
+}
+
+// Create cria um link de pagamento do tenant em nome do operador autenticado
+func (h *PaymentLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req PaymentLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+	req.CreatedBy = supportOperator(r)
+
+	link, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		respondWithPaymentLinkError(w, err, "Erro ao criar link de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, link)
+}
+
+// Get retorna o link de pagamento do tenant com as tentativas de pagamento
+func (h *PaymentLinkHandler) Get(w http.ResponseWriter, r *http.Request) {
+	link, err := h.service.Get(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["linkId"])
+	if err != nil {
+		respondWithPaymentLinkError(w, err, "Erro ao recuperar link de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, link)
+}
+
+// Cancel cancela um link ainda não pago em nome do operador autenticado
+func (h *PaymentLinkHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	link, err := h.service.Cancel(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["linkId"], supportOperator(r))
+	if err != nil {
+		respondWithPaymentLinkError(w, err, "Erro ao cancelar link de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, link)
+}
+
+// PaymentLinkCheckoutHandler expõe o checkout alojado dos links de pagamento aos pagadores. Os pedidos
+// são autenticados pelos parâmetros assinados no URL do link (amount, currency, exp e sig)
+type PaymentLinkCheckoutHandler struct {
+	service *PaymentLinkService
+}
+
+// NewPaymentLinkCheckoutHandler cria uma nova instância do PaymentLinkCheckoutHandler
+func NewPaymentLinkCheckoutHandler(service *PaymentLinkService) *PaymentLinkCheckoutHandler {
+	return &PaymentLinkCheckoutHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *PaymentLinkCheckoutHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(PaymentLinkCheckoutPath+"{linkId}", h.Show).Methods(http.MethodGet)
+	router.HandleFunc(PaymentLinkCheckoutPath+"{linkId}", h.Pay).Methods(http.MethodPost)
+}
+
+// Show apresenta o link ao pagador, sem as tentativas nem os operadores
+func (h *PaymentLinkCheckoutHandler) Show(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	link, err := h.service.Verify(r.Context(), mux.Vars(r)["linkId"], r.URL.Query())
+	if err != nil {
+		respondWithPaymentLinkError(w, err, "Erro ao recuperar link de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, link.Checkout())
+}
+
+// Pay paga o link com o meio de pagamento do pagador
+func (h *PaymentLinkCheckoutHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	var checkout PaymentLinkCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&checkout); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	checkout.ClientIP, _, _ = net.SplitHostPort(r.RemoteAddr)
+	checkout.UserAgent = r.UserAgent()
+
+	link, err := h.service.Pay(r.Context(), mux.Vars(r)["linkId"], r.URL.Query(), checkout)
+	if err != nil {
+		respondWithPaymentLinkError(w, err, "Erro ao pagar link de pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, link.Checkout())
+}
+
+// respondWithPaymentLinkError mapeia os erros dos links de pagamento para respostas HTTP
+func respondWithPaymentLinkError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrPaymentLinkNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Link de pagamento não encontrado")
+	case errors.Is(err, ErrPaymentLinkInvalidSignature):
+		respondWithError(w, http.StatusForbidden, "invalid_signature", ErrPaymentLinkInvalidSignature.Error())
+	case errors.Is(err, ErrPaymentLinkExpired):
+		respondWithError(w, http.StatusGone, "expired", err.Error())
+	case errors.Is(err, ErrPaymentLinkNotActive), errors.Is(err, ErrPaymentLinkProcessing), errors.Is(err, ErrPaymentLinkStatusChanged):
+		respondWithError(w, http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, ErrPaymentLinkPaymentFailed):
+		respondWithError(w, http.StatusPaymentRequired, "payment_failed", err.Error())
+	case errors.Is(err, ErrPaymentLinkInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
@@ -0,0 +1,170 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Estados de um link de pagamento
+const (
+	PaymentLinkStatusActive     = "active"
+	PaymentLinkStatusProcessing = "processing" // Pagamento do link em curso no checkout
+	PaymentLinkStatusPaid       = "paid"
+	PaymentLinkStatusExpired    = "expired"
+	PaymentLinkStatusCancelled  = "cancelled"
+)
+
+// Desfecho das tentativas de pagamento no checkout do link
+const (
+	PaymentLinkAttemptApproved = "approved"
+	PaymentLinkAttemptFailed   = "failed"
+)
+
+// Valores padrão dos links de pagamento
+const (
+	DefaultPaymentLinkTTL            = 7 * 24 * time.Hour
+	DefaultPaymentLinkMaxTTL         = 90 * 24 * time.Hour
+	DefaultPaymentLinkExpiryInterval = time.Minute
+	DefaultPaymentLinkMaxAttempts    = 20 // Tentativas de pagamento guardadas por link
+
+	// PaymentLinkCheckoutPath é o caminho do checkout alojado dos links
+	PaymentLinkCheckoutPath = "/pay/"
+
+	paymentLinkMaxDescription  = 500
+	paymentLinkSignatureLength = 32 // Bytes do HMAC incluídos no link
+)
+
+// DefaultPaymentLinkPaymentMethods são os meios de pagamento aceites no checkout quando o link não os indica
+var DefaultPaymentLinkPaymentMethods = []string{PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodDigitalWallet}
+
+// paymentLinkExcludedMethods são os meios que não concluem o pagamento no checkout do link
+var paymentLinkExcludedMethods = map[string]bool{
+	PaymentMethodQRCode:     true,
+	PaymentMethodInstalment: true,
+	PaymentMethodEFTPOS:     true,
+	PaymentMethodRefund:     true,
+	PaymentMethodRecurring:  true,
+	PaymentMethodRemittance: true,
+	PaymentMethodCrypto:     true,
+	PaymentMethodBoleto:     true,
+}
+
+// Erros dos links de pagamento
+var (
+	ErrPaymentLinkNotFound         = errors.New("link de pagamento não encontrado")
+	ErrPaymentLinkInvalid          = errors.New("link de pagamento inválido")
+	ErrPaymentLinkInvalidSignature = errors.New("assinatura do link de pagamento inválida")
+	ErrPaymentLinkExpired          = errors.New("link de pagamento expirado")
+	ErrPaymentLinkNotActive        = errors.New("link de pagamento já pago ou cancelado")
+	ErrPaymentLinkProcessing       = errors.New("pagamento do link já em processamento")
+	ErrPaymentLinkStatusChanged    = errors.New("estado do link de pagamento alterado por outro pedido")
+	ErrPaymentLinkPaymentFailed    = errors.New("pagamento do link recusado")
+)
+
+// PaymentLinkConfig contém configurações dos links de pagamento
+type PaymentLinkConfig struct {
+	SigningSecret  string        `json:"-"`               // Segredo HMAC que vincula valor, moeda e validade aos links
+	BaseURL        string        `json:"base_url"`        // Endereço público do checkout (ex.: "https://pay.innovabiz.com")
+	TTL            time.Duration `json:"ttl"`             // Validade padrão dos links (padrão 7 dias)
+	MaxTTL         time.Duration `json:"max_ttl"`         // Validade máxima pedida na criação (padrão 90 dias)
+	ExpiryInterval time.Duration `json:"expiry_interval"` // Periodicidade da expiração dos links não pagos
+}
+
+// PaymentLinkRequest é o pedido de criação de um link de pagamento de uma fatura
+type PaymentLinkRequest struct {
+	TenantID         string     `json:"-"`
+	MerchantID       string     `json:"merchant_id"`
+	RegionCode       string     `json:"region_code"`
+	Amount           float64    `json:"amount"`
+	Currency         string     `json:"currency"`
+	Description      string     `json:"description,omitempty"`
+	InvoiceReference string     `json:"invoice_reference,omitempty"` // Número da fatura do comerciante
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`        // Padrão: TTL após a criação
+	PaymentMethods   []string   `json:"payment_methods,omitempty"`   // Padrão: DefaultPaymentLinkPaymentMethods
+	CreatedBy        string     `json:"-"`
+}
+
+// PaymentLinkAttempt regista uma tentativa de pagamento no checkout do link
+type PaymentLinkAttempt struct {
+	TransactionID string    `json:"transaction_id"`
+	PaymentMethod string    `json:"payment_method"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	AttemptedAt   time.Time `json:"attempted_at"`
+}
+
+// PaymentLink é um link partilhável que cobra um valor fixo ao comerciante até à validade.
+// O valor, a moeda e a validade seguem no URL assinados, pelo que um link adulterado é recusado
+type PaymentLink struct {
+	ID               string               `json:"id" db:"id"`
+	TenantID         string               `json:"tenant_id" db:"tenant_id"`
+	MerchantID       string               `json:"merchant_id" db:"merchant_id"`
+	RegionCode       string               `json:"region_code" db:"region_code"`
+	Amount           float64              `json:"amount" db:"amount"`
+	Currency         string               `json:"currency" db:"currency"`
+	Description      string               `json:"description,omitempty" db:"description"`
+	InvoiceReference string               `json:"invoice_reference,omitempty" db:"invoice_reference"`
+	PaymentMethods   []string             `json:"payment_methods" db:"-"`
+	URL              string               `json:"url" db:"url"`
+	Status           string               `json:"status" db:"status"`
+	TransactionID    string               `json:"transaction_id,omitempty" db:"transaction_id"` // Transação que pagou o link
+	Reference        string               `json:"reference,omitempty" db:"reference"`           // Autorização do pagamento
+	Attempts         []PaymentLinkAttempt `json:"attempts,omitempty" db:"-"`
+	CreatedBy        string               `json:"created_by,omitempty" db:"created_by"`
+	CancelledBy      string               `json:"cancelled_by,omitempty" db:"cancelled_by"`
+	CreatedAt        time.Time            `json:"created_at" db:"created_at"`
+	ExpiresAt        time.Time            `json:"expires_at" db:"expires_at"`
+	PaidAt           *time.Time           `json:"paid_at,omitempty" db:"paid_at"`
+	CancelledAt      *time.Time           `json:"cancelled_at,omitempty" db:"cancelled_at"`
+}
+
+// PaymentLinkFilter seleciona os links de pagamento listados
+type PaymentLinkFilter struct {
+	TenantID   string
+	MerchantID string
+	Status     string
+}
+
+// PaymentLinkCheckout é a vista pública do link apresentada no checkout, sem as tentativas nem os operadores
+type PaymentLinkCheckout struct {
+	ID               string    `json:"id"`
+	MerchantID       string    `json:"merchant_id"`
+	Amount           float64   `json:"amount"`
+	Currency         string    `json:"currency"`
+	Description      string    `json:"description,omitempty"`
+	InvoiceReference string    `json:"invoice_reference,omitempty"`
+	PaymentMethods   []string  `json:"payment_methods"`
+	Status           string    `json:"status"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	TransactionID    string    `json:"transaction_id,omitempty"`
+	Reference        string    `json:"reference,omitempty"`
+}
+
+// PaymentLinkCheckoutRequest é o pagamento submetido pelo pagador no checkout do link; o valor e a
+// moeda vêm sempre do link
+type PaymentLinkCheckoutRequest struct {
+	UserID            string                 `json:"user_id"`
+	PaymentMethod     string                 `json:"payment_method"`
+	MFALevel          string                 `json:"mfa_level,omitempty"`
+	DeviceFingerprint string                 `json:"device_fingerprint,omitempty"`
+	PaymentDetails    map[string]interface{} `json:"payment_details"`
+	ClientIP          string                 `json:"-"`
+	UserAgent         string                 `json:"-"`
+}
+
+// Checkout retorna a vista pública do link apresentada ao pagador
+func (l *PaymentLink) Checkout() PaymentLinkCheckout {
+	return PaymentLinkCheckout{
+		ID:               l.ID,
+		MerchantID:       l.MerchantID,
+		Amount:           l.Amount,
+		Currency:         l.Currency,
+		Description:      l.Description,
+		InvoiceReference: l.InvoiceReference,
+		PaymentMethods:   l.PaymentMethods,
+		Status:           l.Status,
+		ExpiresAt:        l.ExpiresAt,
+		TransactionID:    l.TransactionID,
+		Reference:        l.Reference,
+	}
+}
@@ -0,0 +1,188 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresPaymentLinkStore implementa PaymentLinkStore para PostgreSQL
+type PostgresPaymentLinkStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresPaymentLinkStore cria uma nova instância de PostgresPaymentLinkStore
+func NewPostgresPaymentLinkStore(db *sqlx.DB) *PostgresPaymentLinkStore {
+	return &PostgresPaymentLinkStore{db: db}
+}
+
+// dbPaymentLink é a representação de PaymentLink na base de dados
+type dbPaymentLink struct {
+	PaymentLink
+	PaymentMethodsJSON []byte `db:"payment_methods"`
+	AttemptsJSON       []byte `db:"attempts"`
+	FromStatus         string `db:"from_status"`
+}
+
+// paymentLinkColumns são as colunas lidas nas consultas de links de pagamento
+const paymentLinkColumns = `id, tenant_id, merchant_id, region_code, amount, currency, description, invoice_reference,
+	payment_methods, url, status, transaction_id, reference, attempts, created_by, cancelled_by, created_at, expires_at,
+	paid_at, cancelled_at`
+
+// newDBPaymentLink codifica os meios de pagamento e as tentativas em JSONB
+func newDBPaymentLink(link *PaymentLink, fromStatus string) (*dbPaymentLink, error) {
+	row := &dbPaymentLink{PaymentLink: *link, FromStatus: fromStatus}
+	var err error
+	if row.PaymentMethodsJSON, err = json.Marshal(link.PaymentMethods); err != nil {
+		return nil, fmt.Errorf("falha ao codificar meios de pagamento do link: %w", err)
+	}
+	attempts := link.Attempts
+	if attempts == nil {
+		attempts = []PaymentLinkAttempt{}
+	}
+	if row.AttemptsJSON, err = json.Marshal(attempts); err != nil {
+		return nil, fmt.Errorf("falha ao codificar tentativas do link: %w", err)
+	}
+	return row, nil
+}
+
+// CreatePaymentLink grava um novo link de pagamento
+func (r *PostgresPaymentLinkStore) CreatePaymentLink(ctx context.Context, link *PaymentLink) error {
+	row, err := newDBPaymentLink(link, "")
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.payment_links (
+			id, tenant_id, merchant_id, region_code, amount, currency, description, invoice_reference,
+			payment_methods, url, status, transaction_id, reference, attempts, created_by, cancelled_by, created_at, expires_at,
+			paid_at, cancelled_at
+		) VALUES (
+			:id, :tenant_id, :merchant_id, :region_code, :amount, :currency, :description, :invoice_reference,
+			:payment_methods, :url, :status, :transaction_id, :reference, :attempts, :created_by, :cancelled_by, :created_at, :expires_at,
+			:paid_at, :cancelled_at
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar link de pagamento: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePaymentLink grava o estado e as tentativas do link se o estado atual for fromStatus
+func (r *PostgresPaymentLinkStore) UpdatePaymentLink(ctx context.Context, link *PaymentLink, fromStatus string) error {
+	row, err := newDBPaymentLink(link, fromStatus)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE payment_gateway.payment_links SET
+			status = :status,
+			transaction_id = :transaction_id,
+			reference = :reference,
+			attempts = :attempts,
+			cancelled_by = :cancelled_by,
+			paid_at = :paid_at,
+			cancelled_at = :cancelled_at
+		WHERE id = :id AND status = :from_status
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, row)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar link de pagamento: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+	if affected == 0 {
+		if _, err := r.GetPaymentLink(ctx, link.ID); err != nil {
+			return err
+		}
+		return ErrPaymentLinkStatusChanged
+	}
+
+	return nil
+}
+
+// GetPaymentLink recupera o link de pagamento
+func (r *PostgresPaymentLinkStore) GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	var row dbPaymentLink
+	query := `SELECT ` + paymentLinkColumns + ` FROM payment_gateway.payment_links WHERE id = $1`
+	if err := r.db.GetContext(ctx, &row, query, linkID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPaymentLinkNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar link de pagamento: %w", err)
+	}
+	return row.decode()
+}
+
+// ListPaymentLinks lista os links do filtro, mais recentes primeiro
+func (r *PostgresPaymentLinkStore) ListPaymentLinks(ctx context.Context, filter PaymentLinkFilter) ([]*PaymentLink, error) {
+	var rows []dbPaymentLink
+	query := `SELECT ` + paymentLinkColumns + ` FROM payment_gateway.payment_links
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR merchant_id = $2) AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC, id`
+	if err := r.db.SelectContext(ctx, &rows, query, filter.TenantID, filter.MerchantID, filter.Status); err != nil {
+		return nil, fmt.Errorf("falha ao listar links de pagamento: %w", err)
+	}
+	return decodePaymentLinks(rows)
+}
+
+// ExpirePaymentLinks marca como expirados os links ativos fora da validade numa única instrução
+func (r *PostgresPaymentLinkStore) ExpirePaymentLinks(ctx context.Context, now time.Time) ([]*PaymentLink, error) {
+	var rows []dbPaymentLink
+	query := `
+		UPDATE payment_gateway.payment_links SET status = 'expired'
+		WHERE status = 'active' AND expires_at <= $1
+		RETURNING ` + paymentLinkColumns
+	if err := r.db.SelectContext(ctx, &rows, query, now); err != nil {
+		return nil, fmt.Errorf("falha ao expirar links de pagamento: %w", err)
+	}
+
+	links, err := decodePaymentLinks(rows)
+	if err != nil {
+		return nil, err
+	}
+	sortPaymentLinks(links)
+	return links, nil
+}
+
+// decodePaymentLinks descodifica os campos gravados em JSONB
+func decodePaymentLinks(rows []dbPaymentLink) ([]*PaymentLink, error) {
+	links := make([]*PaymentLink, 0, len(rows))
+	for i := range rows {
+		link, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// decode descodifica os meios de pagamento e as tentativas
+func (row *dbPaymentLink) decode() (*PaymentLink, error) {
+	link := row.PaymentLink
+	if len(row.PaymentMethodsJSON) > 0 {
+		if err := json.Unmarshal(row.PaymentMethodsJSON, &link.PaymentMethods); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar meios de pagamento do link: %w", err)
+		}
+	}
+	if len(row.AttemptsJSON) > 0 {
+		if err := json.Unmarshal(row.AttemptsJSON, &link.Attempts); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar tentativas do link: %w", err)
+		}
+	}
+	return &link, nil
+}
@@ -0,0 +1,479 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// PaymentLinkProcessor processa os pagamentos submetidos no checkout dos links; o
+// BureauPaymentGatewayConnector aplica as mesmas regras dos restantes pagamentos
+type PaymentLinkProcessor interface {
+	ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error)
+}
+
+// PaymentLinkService cria os links de pagamento das faturas, assina o valor, a moeda e a validade no
+// URL e acompanha o estado de cada link até ser pago, cancelado ou expirar
+type PaymentLinkService struct {
+	config        PaymentLinkConfig
+	store         PaymentLinkStore
+	processor     PaymentLinkProcessor
+	signingSecret []byte
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewPaymentLinkService cria o serviço de links de pagamento. Sem SigningSecret é gerado um segredo
+// efémero, e os links deixam de ser válidos ao reiniciar o processo
+func NewPaymentLinkService(config PaymentLinkConfig, store PaymentLinkStore, processor PaymentLinkProcessor) (*PaymentLinkService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-payment-links",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.TTL <= 0 {
+		config.TTL = DefaultPaymentLinkTTL
+	}
+	if config.MaxTTL <= 0 {
+		config.MaxTTL = DefaultPaymentLinkMaxTTL
+	}
+	if config.ExpiryInterval <= 0 {
+		config.ExpiryInterval = DefaultPaymentLinkExpiryInterval
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	logger := obsAdapter.Logger()
+	signingSecret := []byte(config.SigningSecret)
+	if len(signingSecret) == 0 {
+		signingSecret = make([]byte, 32)
+		if _, err := rand.Read(signingSecret); err != nil {
+			return nil, fmt.Errorf("falha ao gerar segredo dos links de pagamento: %w", err)
+		}
+		logger.Warn("Segredo de assinatura dos links de pagamento não configurado, usando segredo efémero")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PaymentLinkService{
+		config:          config,
+		store:           store,
+		processor:       processor,
+		signingSecret:   signingSecret,
+		logger:          logger,
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start inicia a expiração periódica dos links não pagos dentro da validade
+func (s *PaymentLinkService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.ExpiryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ExpireDue(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Expiração de links de pagamento iniciada", "interval", s.config.ExpiryInterval.String())
+}
+
+// Stop interrompe a expiração periódica
+func (s *PaymentLinkService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Create cria o link de pagamento com o valor, a moeda e a validade pedidos e gera o URL assinado
+func (s *PaymentLinkService) Create(ctx context.Context, req PaymentLinkRequest) (*PaymentLink, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "PaymentLinkService.Create")
+	defer span.End()
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	switch {
+	case req.TenantID == "" || req.MerchantID == "":
+		return nil, fmt.Errorf("%w: tenant e comerciante obrigatórios", ErrPaymentLinkInvalid)
+	case roundAmount(req.Amount) <= 0:
+		return nil, fmt.Errorf("%w: valor deve ser positivo", ErrPaymentLinkInvalid)
+	case len(currency) != 3:
+		return nil, fmt.Errorf("%w: moeda deve ser um código ISO 4217", ErrPaymentLinkInvalid)
+	case len(req.Description) > paymentLinkMaxDescription:
+		return nil, fmt.Errorf("%w: descrição com mais de %d caracteres", ErrPaymentLinkInvalid, paymentLinkMaxDescription)
+	}
+
+	methods := req.PaymentMethods
+	if len(methods) == 0 {
+		methods = DefaultPaymentLinkPaymentMethods
+	}
+	for _, method := range methods {
+		if method == "" || paymentLinkExcludedMethods[method] {
+			return nil, fmt.Errorf("%w: meio de pagamento %q não disponível nos links", ErrPaymentLinkInvalid, method)
+		}
+	}
+
+	now := s.now().UTC()
+	expiresAt := now.Add(s.config.TTL)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.UTC()
+	}
+	// A validade segue no link em segundos
+	expiresAt = expiresAt.Truncate(time.Second)
+	if !expiresAt.After(now) || expiresAt.After(now.Add(s.config.MaxTTL)) {
+		return nil, fmt.Errorf("%w: validade deve estar entre a criação e %s", ErrPaymentLinkInvalid,
+			now.Add(s.config.MaxTTL).Format(time.RFC3339))
+	}
+
+	link := &PaymentLink{
+		ID:               fmt.Sprintf("plink-%s", uuid.New().String()),
+		TenantID:         req.TenantID,
+		MerchantID:       req.MerchantID,
+		RegionCode:       req.RegionCode,
+		Amount:           roundAmount(req.Amount),
+		Currency:         currency,
+		Description:      req.Description,
+		InvoiceReference: req.InvoiceReference,
+		PaymentMethods:   append([]string(nil), methods...),
+		Status:           PaymentLinkStatusActive,
+		CreatedBy:        req.CreatedBy,
+		CreatedAt:        now,
+		ExpiresAt:        expiresAt,
+	}
+	link.URL = s.config.BaseURL + PaymentLinkCheckoutPath + link.ID + "?" + s.signedParameters(link).Encode()
+
+	if err := s.store.CreatePaymentLink(ctx, link); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+		"market": link.RegionCode,
+		"status": "created",
+	})
+	s.logger.InfoWithContext(ctx, "Link de pagamento criado",
+		"tenant_id", link.TenantID,
+		"payment_link_id", link.ID,
+		"merchant_id", link.MerchantID,
+		"invoice_reference", link.InvoiceReference,
+		"expires_at", link.ExpiresAt.Format(time.RFC3339),
+		"created_by", link.CreatedBy)
+	return link, nil
+}
+
+// signedParameters retorna os parâmetros do URL do link: valor, moeda, validade e a assinatura que os vincula
+func (s *PaymentLinkService) signedParameters(link *PaymentLink) url.Values {
+	amount := strconv.FormatFloat(link.Amount, 'f', 2, 64)
+	expiresAt := strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+	return url.Values{
+		"amount":   {amount},
+		"currency": {link.Currency},
+		"exp":      {expiresAt},
+		"sig":      {s.signature(link.ID, link.MerchantID, amount, link.Currency, expiresAt)},
+	}
+}
+
+// signature calcula o HMAC-SHA256 que vincula o comerciante, o valor, a moeda e a validade ao link
+func (s *PaymentLinkService) signature(id, merchantID, amount, currency, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte(strings.Join([]string{id, merchantID, amount, currency, expiresAt}, "|")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:paymentLinkSignatureLength])
+}
+
+// Verify valida os parâmetros recebidos no checkout contra o link e a sua assinatura, detetando links
+// adulterados (ex.: valor ou validade alterados) antes de consultar o estado
+func (s *PaymentLinkService) Verify(ctx context.Context, linkID string, params url.Values) (*PaymentLink, error) {
+	link, err := s.store.GetPaymentLink(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySignature(link, params); err != nil {
+		s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+			"market": link.RegionCode,
+			"status": "tampered",
+		})
+		// Evento de segurança: o link foi aberto com parâmetros adulterados
+		s.logger.WarnWithContext(ctx, "Link de pagamento adulterado recusado",
+			"tenant_id", link.TenantID,
+			"payment_link_id", link.ID,
+			"merchant_id", link.MerchantID,
+			"amount", params.Get("amount"),
+			"currency", params.Get("currency"),
+			"exp", params.Get("exp"),
+			"error", err.Error())
+		return nil, err
+	}
+	return s.expire(ctx, link)
+}
+
+// verifySignature compara a assinatura e os parâmetros recebidos com os do link
+func (s *PaymentLinkService) verifySignature(link *PaymentLink, params url.Values) error {
+	amount, currency, expiresAt := params.Get("amount"), params.Get("currency"), params.Get("exp")
+	expected := s.signature(link.ID, link.MerchantID, amount, currency, expiresAt)
+	if !hmac.Equal([]byte(params.Get("sig")), []byte(expected)) {
+		return ErrPaymentLinkInvalidSignature
+	}
+	// Assinatura válida para outros parâmetros só ocorre com o segredo comprometido ou substituído
+	for name, values := range s.signedParameters(link) {
+		if params.Get(name) != values[0] {
+			return fmt.Errorf("%w: parâmetro %s não corresponde ao link", ErrPaymentLinkInvalidSignature, name)
+		}
+	}
+	return nil
+}
+
+// Pay valida o link e submete o pagamento do pagador ao processamento com o valor e a moeda do link.
+// O link fica pago com a autorização do pagamento ou, se o pagamento for recusado, volta a aceitar
+// pagamentos dentro da validade
+func (s *PaymentLinkService) Pay(ctx context.Context, linkID string, params url.Values, checkout PaymentLinkCheckoutRequest) (*PaymentLink, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "PaymentLinkService.Pay")
+	defer span.End()
+
+	link, err := s.Verify(ctx, linkID, params)
+	if err != nil {
+		return nil, err
+	}
+	if checkout.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id obrigatório", ErrPaymentLinkInvalid)
+	}
+	switch link.Status {
+	case PaymentLinkStatusExpired:
+		return nil, ErrPaymentLinkExpired
+	case PaymentLinkStatusProcessing:
+		return nil, ErrPaymentLinkProcessing
+	case PaymentLinkStatusPaid, PaymentLinkStatusCancelled:
+		return nil, fmt.Errorf("%w: %s", ErrPaymentLinkNotActive, link.Status)
+	}
+	if !containsValue(link.PaymentMethods, checkout.PaymentMethod) {
+		return nil, fmt.Errorf("%w: meio de pagamento %q não aceite pelo link", ErrPaymentLinkInvalid, checkout.PaymentMethod)
+	}
+
+	// Reservar o link impede pagamentos simultâneos do mesmo link
+	link.Status = PaymentLinkStatusProcessing
+	if err := s.store.UpdatePaymentLink(ctx, link, PaymentLinkStatusActive); err != nil {
+		return nil, err
+	}
+
+	req := &PaymentRequest{
+		RequestID:      uuid.New().String(),
+		TransactionID:  fmt.Sprintf("tx-%s", uuid.New().String()),
+		TenantID:       link.TenantID,
+		RegionCode:     link.RegionCode,
+		UserID:         checkout.UserID,
+		MerchantID:     link.MerchantID,
+		PaymentMethod:  checkout.PaymentMethod,
+		Amount:         link.Amount,
+		Currency:       link.Currency,
+		Description:    link.Description,
+		MFALevel:       checkout.MFALevel,
+		PaymentDetails: checkout.PaymentDetails,
+		DeviceInfo: DeviceInfo{
+			IPAddress:         checkout.ClientIP,
+			UserAgent:         checkout.UserAgent,
+			DeviceFingerprint: checkout.DeviceFingerprint,
+		},
+		Metadata: map[string]interface{}{
+			"payment_link_id":   link.ID,
+			"invoice_reference": link.InvoiceReference,
+		},
+		Timestamp: s.now().UTC(),
+	}
+	response, paymentErr := s.processor.ProcessPayment(ctx, req)
+	if paymentErr == nil && response.Status != TransactionStatusApproved {
+		paymentErr = fmt.Errorf("pagamento %s: %s", response.Status, response.StatusDescription)
+	}
+
+	attempt := PaymentLinkAttempt{
+		TransactionID: req.TransactionID,
+		PaymentMethod: req.PaymentMethod,
+		Status:        PaymentLinkAttemptApproved,
+		AttemptedAt:   s.now().UTC(),
+	}
+	if paymentErr != nil {
+		span.RecordError(paymentErr)
+		attempt.Status = PaymentLinkAttemptFailed
+		attempt.FailureReason = paymentErr.Error()
+		link.Status = PaymentLinkStatusActive
+		if !s.now().Before(link.ExpiresAt) {
+			link.Status = PaymentLinkStatusExpired
+		}
+	} else {
+		paidAt := attempt.AttemptedAt
+		link.Status = PaymentLinkStatusPaid
+		link.PaidAt = &paidAt
+		link.TransactionID = req.TransactionID
+		link.Reference = response.AuthorizationID
+	}
+	link.Attempts = append(link.Attempts, attempt)
+	if len(link.Attempts) > DefaultPaymentLinkMaxAttempts {
+		link.Attempts = link.Attempts[len(link.Attempts)-DefaultPaymentLinkMaxAttempts:]
+	}
+	if err := s.store.UpdatePaymentLink(ctx, link, PaymentLinkStatusProcessing); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar pagamento do link",
+			"payment_link_id", link.ID,
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+		"market": link.RegionCode,
+		"status": link.Status,
+	})
+	if paymentErr != nil {
+		s.logger.WarnWithContext(ctx, "Pagamento do link recusado",
+			"tenant_id", link.TenantID,
+			"payment_link_id", link.ID,
+			"transaction_id", req.TransactionID,
+			"error", paymentErr.Error())
+		return link, fmt.Errorf("%w: %w", ErrPaymentLinkPaymentFailed, paymentErr)
+	}
+
+	s.logger.InfoWithContext(ctx, "Link de pagamento pago",
+		"tenant_id", link.TenantID,
+		"payment_link_id", link.ID,
+		"transaction_id", link.TransactionID,
+		"reference", link.Reference)
+	return link, nil
+}
+
+// Cancel cancela o link ativo do tenant, que deixa de aceitar pagamentos
+func (s *PaymentLinkService) Cancel(ctx context.Context, tenantID, linkID, cancelledBy string) (*PaymentLink, error) {
+	link, err := s.Get(ctx, tenantID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	switch link.Status {
+	case PaymentLinkStatusActive:
+	case PaymentLinkStatusProcessing:
+		return nil, ErrPaymentLinkProcessing
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPaymentLinkNotActive, link.Status)
+	}
+
+	cancelledAt := s.now().UTC()
+	link.Status = PaymentLinkStatusCancelled
+	link.CancelledAt = &cancelledAt
+	link.CancelledBy = cancelledBy
+	if err := s.store.UpdatePaymentLink(ctx, link, PaymentLinkStatusActive); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+		"market": link.RegionCode,
+		"status": PaymentLinkStatusCancelled,
+	})
+	s.logger.InfoWithContext(ctx, "Link de pagamento cancelado",
+		"tenant_id", tenantID,
+		"payment_link_id", linkID,
+		"cancelled_by", cancelledBy)
+	return link, nil
+}
+
+// Get retorna o link do tenant, marcando-o como expirado se a validade terminou
+func (s *PaymentLinkService) Get(ctx context.Context, tenantID, linkID string) (*PaymentLink, error) {
+	link, err := s.store.GetPaymentLink(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link.TenantID != tenantID {
+		return nil, ErrPaymentLinkNotFound
+	}
+	return s.expire(ctx, link)
+}
+
+// List retorna os links do filtro, mais recentes primeiro
+func (s *PaymentLinkService) List(ctx context.Context, filter PaymentLinkFilter) ([]*PaymentLink, error) {
+	links, err := s.store.ListPaymentLinks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Os links fora da validade ainda não expirados pela execução periódica são apresentados como expirados
+	filtered := make([]*PaymentLink, 0, len(links))
+	for _, link := range links {
+		if link.Status == PaymentLinkStatusActive && !s.now().Before(link.ExpiresAt) {
+			link.Status = PaymentLinkStatusExpired
+		}
+		if filter.Status == "" || link.Status == filter.Status {
+			filtered = append(filtered, link)
+		}
+	}
+	return filtered, nil
+}
+
+// ExpireDue expira os links ativos fora da validade e retorna-os
+func (s *PaymentLinkService) ExpireDue(ctx context.Context) []*PaymentLink {
+	links, err := s.store.ExpirePaymentLinks(ctx, s.now())
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao expirar links de pagamento", "error", err.Error())
+		return nil
+	}
+
+	for _, link := range links {
+		s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+			"market": link.RegionCode,
+			"status": PaymentLinkStatusExpired,
+		})
+	}
+	return links
+}
+
+// expire marca o link ativo como expirado quando a validade terminou; um link em processamento só
+// expira depois de registado o desfecho do pagamento
+func (s *PaymentLinkService) expire(ctx context.Context, link *PaymentLink) (*PaymentLink, error) {
+	if link.Status != PaymentLinkStatusActive || s.now().Before(link.ExpiresAt) {
+		return link, nil
+	}
+
+	link.Status = PaymentLinkStatusExpired
+	err := s.store.UpdatePaymentLink(ctx, link, PaymentLinkStatusActive)
+	if errors.Is(err, ErrPaymentLinkStatusChanged) {
+		// Pago, cancelado ou expirado entretanto por outro pedido
+		return s.store.GetPaymentLink(ctx, link.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_payment_links", map[string]string{
+		"market": link.RegionCode,
+		"status": PaymentLinkStatusExpired,
+	})
+	return link, nil
+}
@@ -0,0 +1,296 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPaymentLinkDeclinedCard é o cartão recusado por saldo insuficiente pelo fakePaymentLinkProcessor
+const testPaymentLinkDeclinedCard = "4000000000009995"
+
+// fakePaymentLinkProcessor recusa o cartão testPaymentLinkDeclinedCard, aprova os restantes e regista os pedidos
+type fakePaymentLinkProcessor struct {
+	mu       sync.Mutex
+	requests []*PaymentRequest
+}
+
+func (p *fakePaymentLinkProcessor) ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	if req.PaymentDetails["card_number"] == testPaymentLinkDeclinedCard {
+		return &PaymentResponse{
+			TransactionID:     req.TransactionID,
+			Status:            TransactionStatusDenied,
+			StatusCode:        "51",
+			StatusDescription: "Saldo insuficiente",
+		}, nil
+	}
+	return &PaymentResponse{
+		TransactionID:   req.TransactionID,
+		Status:          TransactionStatusApproved,
+		AuthorizationID: "AUTH-" + req.TransactionID,
+	}, nil
+}
+
+func (p *fakePaymentLinkProcessor) processed() []*PaymentRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*PaymentRequest(nil), p.requests...)
+}
+
+func newTestPaymentLinkService(t *testing.T) (*PaymentLinkService, *fakePaymentLinkProcessor, *time.Time) {
+	t.Helper()
+
+	processor := &fakePaymentLinkProcessor{}
+	service, err := NewPaymentLinkService(PaymentLinkConfig{
+		SigningSecret: "segredo-links-de-teste",
+		BaseURL:       "https://pay.innovabiz.test/",
+	}, NewInMemoryPaymentLinkStore(), processor)
+	require.NoError(t, err)
+	clock := time.Date(2026, 11, 18, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, processor, &clock
+}
+
+func newTestPaymentLinkRouter(service *PaymentLinkService) *mux.Router {
+	router := mux.NewRouter()
+	NewPaymentLinkHandler(service).RegisterRoutes(router)
+	NewPaymentLinkCheckoutHandler(service).RegisterRoutes(router)
+	return router
+}
+
+// servePaymentLinkCheckout envia o pedido ao checkout alojado no caminho e com os parâmetros do URL do link
+func servePaymentLinkCheckout(t *testing.T, router *mux.Router, method, linkURL string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, linkURL, strings.NewReader(string(payload))))
+	return rec
+}
+
+func testPaymentLinkCheckout(method, cardNumber string) PaymentLinkCheckoutRequest {
+	return PaymentLinkCheckoutRequest{
+		UserID:         "user-1",
+		PaymentMethod:  method,
+		MFALevel:       "high",
+		PaymentDetails: map[string]interface{}{"card_number": cardNumber},
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(raw)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestPaymentLinkSignedParameters(t *testing.T) {
+	service, processor, clock := newTestPaymentLinkService(t)
+	router := newTestPaymentLinkRouter(service)
+	ctx := context.Background()
+
+	expiresAt := clock.Add(48 * time.Hour)
+	link, err := service.Create(ctx, PaymentLinkRequest{
+		TenantID:         "tenant-1",
+		MerchantID:       "merchant-1",
+		RegionCode:       RegionUSA,
+		Amount:           250.5,
+		Currency:         "usd",
+		Description:      "Fatura de novembro",
+		InvoiceReference: "FT 2026/1187",
+		ExpiresAt:        &expiresAt,
+		CreatedBy:        "ops-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PaymentLinkStatusActive, link.Status)
+	assert.Equal(t, "USD", link.Currency)
+	assert.Equal(t, DefaultPaymentLinkPaymentMethods, link.PaymentMethods)
+	require.True(t, strings.HasPrefix(link.URL, "https://pay.innovabiz.test/pay/"+link.ID+"?"), link.URL)
+	checkoutPath := strings.TrimPrefix(link.URL, "https://pay.innovabiz.test")
+
+	rec := servePaymentLinkCheckout(t, router, http.MethodGet, checkoutPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	var checkout PaymentLinkCheckout
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &checkout))
+	assert.Equal(t, 250.5, checkout.Amount)
+	assert.Equal(t, "FT 2026/1187", checkout.InvoiceReference)
+	assert.NotContains(t, rec.Body.String(), "ops-1")
+
+	// Qualquer parâmetro alterado, ou a assinatura de outro link, invalida o link
+	other, err := service.Create(ctx, PaymentLinkRequest{TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 1, Currency: "USD"})
+	require.NoError(t, err)
+	tests := map[string]func(params url.Values){
+		"valor":               func(params url.Values) { params.Set("amount", "2.50") },
+		"moeda":               func(params url.Values) { params.Set("currency", "EUR") },
+		"validade":            func(params url.Values) { params.Set("exp", strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10)) },
+		"sem assinatura":      func(params url.Values) { params.Del("sig") },
+		"assinatura de outro": func(params url.Values) { params.Set("sig", mustParseURL(t, other.URL).Query().Get("sig")) },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			tampered := mustParseURL(t, checkoutPath)
+			params := tampered.Query()
+			tamper(params)
+			tampered.RawQuery = params.Encode()
+
+			rec := servePaymentLinkCheckout(t, router, http.MethodGet, tampered.String(), nil)
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			rec = servePaymentLinkCheckout(t, router, http.MethodPost, tampered.String(),
+				testPaymentLinkCheckout(PaymentMethodCard, "4111111111111111"))
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+	assert.Empty(t, processor.processed(), "links adulterados não chegam ao processamento")
+	rec = servePaymentLinkCheckout(t, router, http.MethodGet, "/pay/plink-inexistente?"+mustParseURL(t, link.URL).RawQuery, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Depois da validade o link é apresentado como expirado e deixa de aceitar pagamentos
+	*clock = clock.Add(DefaultPaymentLinkTTL)
+	rec = servePaymentLinkCheckout(t, router, http.MethodGet, checkoutPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &checkout))
+	assert.Equal(t, PaymentLinkStatusExpired, checkout.Status)
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, checkoutPath,
+		testPaymentLinkCheckout(PaymentMethodCard, "4111111111111111"))
+	assert.Equal(t, http.StatusGone, rec.Code)
+	expired := service.ExpireDue(ctx) // O outro link, expirado pela execução periódica
+	require.Len(t, expired, 1)
+	assert.Equal(t, other.ID, expired[0].ID)
+	links, err := service.List(ctx, PaymentLinkFilter{TenantID: "tenant-1", Status: PaymentLinkStatusExpired})
+	require.NoError(t, err)
+	assert.Len(t, links, 2)
+
+	tooLate := clock.Add(DefaultPaymentLinkMaxTTL + time.Hour)
+	for name, req := range map[string]PaymentLinkRequest{
+		"sem tenant":         {MerchantID: "merchant-1", Amount: 10, Currency: "USD"},
+		"valor nulo":         {TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 0, Currency: "USD"},
+		"moeda inválida":     {TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 10, Currency: "DOLLAR"},
+		"QR code":            {TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 10, Currency: "USD", PaymentMethods: []string{PaymentMethodQRCode}},
+		"validade excessiva": {TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 10, Currency: "USD", ExpiresAt: &tooLate},
+	} {
+		_, err := service.Create(ctx, req)
+		assert.ErrorIs(t, err, ErrPaymentLinkInvalid, name)
+	}
+}
+
+func TestPaymentLinkHostedCheckout(t *testing.T) {
+	service, processor, _ := newTestPaymentLinkService(t)
+	router := newTestPaymentLinkRouter(service)
+
+	req := httptest.NewRequest(http.MethodPost, "/support/payment-links", strings.NewReader(
+		`{"merchant_id": "merchant-1", "region_code": "US", "amount": 120, "currency": "USD",
+		"invoice_reference": "FT 2026/1188", "payment_methods": ["card"]}`))
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	req = req.WithContext(WithSupportPrincipal(req.Context(), &SupportPrincipal{Operator: "ops-1"}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var link PaymentLink
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &link))
+	assert.Equal(t, "ops-1", link.CreatedBy)
+	checkoutPath := strings.TrimPrefix(link.URL, "https://pay.innovabiz.test")
+
+	// Um meio não aceite pelo link é recusado antes do processamento
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, checkoutPath,
+		testPaymentLinkCheckout(PaymentMethodDigitalWallet, "4111111111111111"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, processor.processed())
+
+	// O pagamento recusado fica registado e o link continua a aceitar pagamentos
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, checkoutPath,
+		testPaymentLinkCheckout(PaymentMethodCard, testPaymentLinkDeclinedCard))
+	assert.Equal(t, http.StatusPaymentRequired, rec.Code, rec.Body.String())
+	current, err := service.Get(context.Background(), "tenant-1", link.ID)
+	require.NoError(t, err)
+	assert.Equal(t, PaymentLinkStatusActive, current.Status)
+	require.Len(t, current.Attempts, 1)
+	assert.Equal(t, PaymentLinkAttemptFailed, current.Attempts[0].Status)
+	assert.Contains(t, current.Attempts[0].FailureReason, "Saldo insuficiente")
+
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, checkoutPath,
+		testPaymentLinkCheckout(PaymentMethodCard, "4111111111111111"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var paid PaymentLinkCheckout
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &paid))
+	assert.Equal(t, PaymentLinkStatusPaid, paid.Status)
+	assert.Equal(t, "AUTH-"+paid.TransactionID, paid.Reference)
+
+	// O pagamento é processado com o valor e a moeda do link
+	processed := processor.processed()
+	require.Len(t, processed, 2)
+	payment := processed[1]
+	assert.Equal(t, paid.TransactionID, payment.TransactionID)
+	assert.Equal(t, "tenant-1", payment.TenantID)
+	assert.Equal(t, RegionUSA, payment.RegionCode)
+	assert.Equal(t, "merchant-1", payment.MerchantID)
+	assert.Equal(t, 120.0, payment.Amount)
+	assert.Equal(t, "USD", payment.Currency)
+	assert.Equal(t, link.ID, payment.Metadata["payment_link_id"])
+	assert.Equal(t, "FT 2026/1188", payment.Metadata["invoice_reference"])
+
+	// Um link pago não volta a ser pago nem pode ser cancelado
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, checkoutPath,
+		testPaymentLinkCheckout(PaymentMethodCard, "4111111111111111"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = serveSupport(router, http.MethodPost, "/support/payment-links/"+link.ID+"/cancel")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveSupport(router, http.MethodGet, "/support/payment-links/"+link.ID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &link))
+	assert.Equal(t, PaymentLinkStatusPaid, link.Status)
+	require.NotNil(t, link.PaidAt)
+	require.Len(t, link.Attempts, 2)
+	assert.Equal(t, PaymentLinkAttemptApproved, link.Attempts[1].Status)
+	assert.Equal(t, paid.TransactionID, link.Attempts[1].TransactionID)
+
+	// Os links de outros tenants não são visíveis no suporte
+	req = httptest.NewRequest(http.MethodGet, "/support/payment-links/"+link.ID, nil)
+	req.Header.Set("X-Tenant-ID", "tenant-2")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPaymentLinkCancel(t *testing.T) {
+	service, processor, _ := newTestPaymentLinkService(t)
+	router := newTestPaymentLinkRouter(service)
+	ctx := context.Background()
+
+	link, err := service.Create(ctx, PaymentLinkRequest{TenantID: "tenant-1", MerchantID: "merchant-1", Amount: 75, Currency: "EUR"})
+	require.NoError(t, err)
+
+	rec := serveSupport(router, http.MethodPost, "/support/payment-links/"+link.ID+"/cancel")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var cancelled PaymentLink
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cancelled))
+	assert.Equal(t, PaymentLinkStatusCancelled, cancelled.Status)
+	assert.Equal(t, "ops-1", cancelled.CancelledBy)
+
+	rec = servePaymentLinkCheckout(t, router, http.MethodPost, strings.TrimPrefix(link.URL, "https://pay.innovabiz.test"),
+		testPaymentLinkCheckout(PaymentMethodCard, "4111111111111111"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Empty(t, processor.processed())
+
+	_, err = service.Cancel(ctx, "tenant-1", link.ID, "ops-1")
+	assert.ErrorIs(t, err, ErrPaymentLinkNotActive)
+}
@@ -0,0 +1,132 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PaymentLinkStore define a persistência dos links de pagamento
+type PaymentLinkStore interface {
+	// CreatePaymentLink grava um novo link de pagamento
+	CreatePaymentLink(ctx context.Context, link *PaymentLink) error
+
+	// UpdatePaymentLink grava o link se o estado atual for fromStatus; retorna
+	// ErrPaymentLinkStatusChanged quando o estado já mudou
+	UpdatePaymentLink(ctx context.Context, link *PaymentLink, fromStatus string) error
+
+	// GetPaymentLink recupera o link; retorna ErrPaymentLinkNotFound quando não existe
+	GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error)
+
+	// ListPaymentLinks lista os links do filtro, mais recentes primeiro
+	ListPaymentLinks(ctx context.Context, filter PaymentLinkFilter) ([]*PaymentLink, error)
+
+	// ExpirePaymentLinks marca como expirados os links ativos com validade até now e retorna-os
+	ExpirePaymentLinks(ctx context.Context, now time.Time) ([]*PaymentLink, error)
+}
+
+// InMemoryPaymentLinkStore armazena os links de pagamento em memória
+type InMemoryPaymentLinkStore struct {
+	links map[string]*PaymentLink
+	mutex sync.RWMutex
+}
+
+// NewInMemoryPaymentLinkStore cria um novo armazenamento em memória
+func NewInMemoryPaymentLinkStore() *InMemoryPaymentLinkStore {
+	return &InMemoryPaymentLinkStore{links: make(map[string]*PaymentLink)}
+}
+
+// CreatePaymentLink grava uma cópia do link
+func (s *InMemoryPaymentLinkStore) CreatePaymentLink(ctx context.Context, link *PaymentLink) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.links[link.ID] = copyPaymentLink(link)
+	return nil
+}
+
+// UpdatePaymentLink grava uma cópia do link se o estado atual for fromStatus
+func (s *InMemoryPaymentLinkStore) UpdatePaymentLink(ctx context.Context, link *PaymentLink, fromStatus string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, ok := s.links[link.ID]
+	if !ok {
+		return ErrPaymentLinkNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrPaymentLinkStatusChanged
+	}
+	s.links[link.ID] = copyPaymentLink(link)
+	return nil
+}
+
+// GetPaymentLink retorna uma cópia do link
+func (s *InMemoryPaymentLinkStore) GetPaymentLink(ctx context.Context, linkID string) (*PaymentLink, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	link, ok := s.links[linkID]
+	if !ok {
+		return nil, ErrPaymentLinkNotFound
+	}
+	return copyPaymentLink(link), nil
+}
+
+// ListPaymentLinks retorna cópias dos links do filtro
+func (s *InMemoryPaymentLinkStore) ListPaymentLinks(ctx context.Context, filter PaymentLinkFilter) ([]*PaymentLink, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	links := make([]*PaymentLink, 0)
+	for _, link := range s.links {
+		if filter.TenantID != "" && link.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.MerchantID != "" && link.MerchantID != filter.MerchantID {
+			continue
+		}
+		if filter.Status != "" && link.Status != filter.Status {
+			continue
+		}
+		links = append(links, copyPaymentLink(link))
+	}
+	sortPaymentLinks(links)
+	return links, nil
+}
+
+// ExpirePaymentLinks marca como expirados os links ativos fora da validade
+func (s *InMemoryPaymentLinkStore) ExpirePaymentLinks(ctx context.Context, now time.Time) ([]*PaymentLink, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	links := make([]*PaymentLink, 0)
+	for _, link := range s.links {
+		if link.Status != PaymentLinkStatusActive || now.Before(link.ExpiresAt) {
+			continue
+		}
+		link.Status = PaymentLinkStatusExpired
+		links = append(links, copyPaymentLink(link))
+	}
+	sortPaymentLinks(links)
+	return links, nil
+}
+
+// copyPaymentLink copia o link, os meios de pagamento e as tentativas
+func copyPaymentLink(link *PaymentLink) *PaymentLink {
+	copied := *link
+	copied.PaymentMethods = append([]string(nil), link.PaymentMethods...)
+	copied.Attempts = append([]PaymentLinkAttempt(nil), link.Attempts...)
+	return &copied
+}
+
+// sortPaymentLinks ordena os links do mais recente para o mais antigo
+func sortPaymentLinks(links []*PaymentLink) {
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CreatedAt.After(links[j].CreatedAt)
+		}
+		return links[i].ID < links[j].ID
+	})
+}
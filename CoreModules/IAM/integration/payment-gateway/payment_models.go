@@ -6,6 +6,34 @@ import (
 	cv "github.com/innovabizdevops/innovabiz-iam/integration/cross-verification"
 )
 
+// Meios de pagamento suportados pelo gateway
+const (
+	PaymentMethodCard          = "card"
+	PaymentMethodBankTransfer  = "bank_transfer"
+	PaymentMethodDigitalWallet = "digital_wallet"
+	PaymentMethodCrypto        = "cryptocurrency"
+	PaymentMethodInstalment    = "instalment"
+	PaymentMethodRefund        = "refund"
+	PaymentMethodRecurring     = "recurring"
+	PaymentMethodQRCode        = "qr_code"
+	PaymentMethodMobileMoney   = "mobile_money"
+	PaymentMethodBoleto        = "boleto"
+	PaymentMethodPIX           = "pix"
+	PaymentMethodEFTPOS        = "eftpos"
+	PaymentMethodSEPA          = "sepa"
+	PaymentMethodRemittance    = "remittance"
+)
+
+// Códigos dos mercados com regras regulatórias próprias; RegionGlobal identifica as regras de todos os mercados
+const (
+	RegionGlobal     = "global"
+	RegionAngola     = "AO"
+	RegionBrazil     = "BR"
+	RegionEU         = "EU"
+	RegionMozambique = "MZ"
+	RegionUSA        = "US"
+)
+
 // PaymentRequest contém os dados necessários para processar um pagamento
 type PaymentRequest struct {
 	RequestID         string                 `json:"request_id"`
@@ -19,8 +47,15 @@ type PaymentRequest struct {
 	PaymentType       string                 `json:"payment_type"`
 	MerchantID        string                 `json:"merchant_id"`
 	MerchantCategory  string                 `json:"merchant_category"`
+	CustomerTier      string                 `json:"customer_tier,omitempty"`
 	Description       string                 `json:"description"`
 	UserData          UserData               `json:"user_data"`
+	BillingAddress    *Address               `json:"billing_address,omitempty"`
+	ShippingAddress   *Address               `json:"shipping_address,omitempty"`
+	PaymentDetails    map[string]interface{} `json:"payment_details,omitempty"` // Dados do meio de pagamento (IBAN, BIC, chave PIX...)
+	ThreeDSData       map[string]interface{} `json:"three_ds_data,omitempty"`
+	MFALevel          string                 `json:"mfa_level,omitempty"`
+	PreviousTransactions []string            `json:"previous_transactions,omitempty"` // Transações recentes do usuário
 	FinancialData     FinancialData          `json:"financial_data"`
 	DeviceInfo        DeviceInfo             `json:"device_info"`
 	FinancialProducts []string               `json:"financial_products"`
@@ -29,6 +64,15 @@ type PaymentRequest struct {
 	InternationalPayment bool                `json:"international_payment"`
 	HighRiskCategory  bool                   `json:"high_risk_category"`
 	PaymentReference  string                 `json:"payment_reference,omitempty"`
+	CardTokenID       string                 `json:"card_token_id,omitempty"` // Cartão tokenizado usado na autorização
+	Remittance        *RemittanceDetails     `json:"remittance,omitempty"`    // Corredor e beneficiário das remessas internacionais
+	Crypto            *CryptoPaymentDetails  `json:"crypto,omitempty"`        // Criptoativo, carteira do pagador e dados da travel rule
+	Boleto            *BoletoPaymentDetails  `json:"boleto,omitempty"`        // Vencimento, pagador e encargos do boleto bancário
+	SCAExemption      *SCAExemptionDecision  `json:"-"`                       // Decisão de isenção SCA do gateway; nunca lida do pedido
+	InstalmentDelinquency *InstalmentDelinquency `json:"-"`                   // Incumprimento de parcelamentos do usuário, preenchido pelo gateway
+	FraudHistory      *FraudHistory          `json:"-"`                       // Fraudes confirmadas do usuário e do dispositivo e perfil do comerciante, preenchido pelo gateway
+	Sandbox           bool                   `json:"-"`                       // Comerciante em modo sandbox; nunca lido do pedido
+	StoredCredential  *StoredCredentialIndicator `json:"-"`                   // Indicadores CIT/MIT do cartão armazenado, preenchidos pelo serviço de credenciais
 	CallbackURL       string                 `json:"callback_url,omitempty"`
 	Timestamp         time.Time              `json:"timestamp"`
 }
@@ -48,6 +92,9 @@ type PaymentResponse struct {
 	ChallengeRequired     bool                   `json:"challenge_required"`
 	ChallengeDetails      *ChallengeDetails      `json:"challenge_details,omitempty"`
 	DetectedAnomalies     []cv.Anomaly           `json:"detected_anomalies,omitempty"`
+	DeviceAssessment      *DeviceAssessment      `json:"device_assessment,omitempty"`
+	CardCredential        *CardCredential        `json:"card_credential,omitempty"`
+	AcquirerID            string                 `json:"acquirer_id,omitempty"`
 	RiskLevel             string                 `json:"risk_level,omitempty"`
 	VerificationDetails   VerificationDetails    `json:"verification_details,omitempty"`
 	Metadata              map[string]interface{} `json:"metadata,omitempty"`
@@ -0,0 +1,96 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// PSPClient define a chamada de autorização ao PSP
+type PSPClient interface {
+	// Authorize envia o pedido ao PSP; aprovações, recusas, desafios 3DS e autorizações pendentes
+	// são resultados, enquanto os tempos esgotados retornam ErrPSPTimeout, as falhas de rede e as
+	// respostas 5xx ErrPSPUnavailable e as respostas 4xx ErrPSPRequestRejected
+	Authorize(ctx context.Context, req *PSPAuthorizationRequest) (*PSPAuthorizationResponse, error)
+}
+
+// HTTPPSPClient chama a API de autorizações do PSP por HTTP/JSON
+type HTTPPSPClient struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPPSPClient cria o cliente da API de autorizações do PSP
+func NewHTTPPSPClient(config PSPConfig) *HTTPPSPClient {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPSPTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPPSPClient{
+		endpoint:   strings.TrimRight(config.Endpoint, "/"),
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Authorize envia um POST JSON para /v1/authorizations do PSP
+// A transação é a chave de idempotência, para que as novas tentativas não dupliquem a autorização.
+// O corpo contém o número do cartão e nunca é incluído nos erros
+func (c *HTTPPSPClient) Authorize(ctx context.Context, req *PSPAuthorizationRequest) (*PSPAuthorizationResponse, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/v1/authorizations", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, req.TransactionID)
+		if c.apiKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return nil, fmt.Errorf("%w: %v", ErrPSPTimeout, err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPSPUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPSPUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: status %d", ErrPSPUnavailable, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("%w: status %d", ErrPSPRequestRejected, resp.StatusCode)
+	}
+
+	var result PSPAuthorizationResponse
+	if err := json.Unmarshal(body, &result); err != nil || result.Reference == "" {
+		return nil, fmt.Errorf("%w: resposta inválida", ErrPSPUnavailable)
+	}
+	return &result, nil
+}
@@ -0,0 +1,62 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// PSPHandler recebe os webhooks do PSP com o desfecho das autorizações pendentes, assinados no
+// cabeçalho X-PSP-Signature com o segredo partilhado
+type PSPHandler struct {
+	service *PSPService
+}
+
+// NewPSPHandler cria uma nova instância do PSPHandler
+func NewPSPHandler(service *PSPService) *PSPHandler {
+	return &PSPHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *PSPHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/psp/webhooks", h.Webhook).Methods(http.MethodPost)
+}
+
+// Webhook concilia o desfecho de uma autorização pendente e responde com a autorização atualizada
+func (h *PSPHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo do webhook inválido")
+		return
+	}
+	if err := h.service.VerifyWebhookSignature(r.Header.Get(PSPSignatureHeader), body); err != nil {
+		h.service.logger.WarnWithContext(r.Context(), "Webhook do PSP com assinatura inválida",
+			"error", err.Error())
+		respondWithError(w, http.StatusUnauthorized, "invalid_signature", "Assinatura do webhook inválida")
+		return
+	}
+
+	var event PSPWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Reference == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo do webhook inválido")
+		return
+	}
+
+	authorization, err := h.service.CompleteAuthorization(r.Context(), event)
+	switch {
+	case errors.Is(err, ErrPSPAuthorizationNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Autorização não encontrada")
+		return
+	case errors.Is(err, ErrPSPUnknownEvent):
+		respondWithError(w, http.StatusUnprocessableEntity, "invalid_event", err.Error())
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao processar webhook do PSP")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, authorization)
+}
@@ -0,0 +1,173 @@
+package paymentgateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estados de uma autorização no PSP
+const (
+	PSPStatusApproved          = "approved"
+	PSPStatusDeclined          = "declined"
+	PSPStatusPending           = "pending"            // Desfecho enviado depois por webhook
+	PSPStatusChallengeRequired = "challenge_required" // O emissor exige desafio 3DS antes de autorizar
+)
+
+// Eventos enviados pelo PSP ao endpoint de webhooks do gateway
+const (
+	PSPEventAuthorizationApproved = "authorization.approved"
+	PSPEventAuthorizationDeclined = "authorization.declined"
+)
+
+// PSPSignatureHeader transporta a assinatura dos webhooks do PSP, no formato de SignPSPPayload
+const PSPSignatureHeader = "X-PSP-Signature"
+
+// Valores padrão da integração com o PSP
+const (
+	DefaultPSPTimeout = 10 * time.Second
+
+	pspSignatureTolerance = 5 * time.Minute // Desvio máximo do carimbo temporal dos webhooks
+)
+
+// pspPaymentMethods são os meios de pagamento autorizados no PSP; os restantes têm fluxos próprios
+var pspPaymentMethods = map[string]bool{
+	PaymentMethodCard:          true,
+	PaymentMethodBankTransfer:  true,
+	PaymentMethodDigitalWallet: true,
+	PaymentMethodPIX:           true,
+}
+
+// Erros da autorização no PSP
+var (
+	ErrPSPDeclined              = errors.New("pagamento recusado pelo PSP")
+	ErrPSP3DSChallengeRequired  = errors.New("o emissor exige desafio 3DS")
+	ErrPSPTimeout               = errors.New("tempo esgotado na resposta do PSP")
+	ErrPSPUnavailable           = errors.New("PSP indisponível")
+	ErrPSPRequestRejected       = errors.New("PSP rejeitou o pedido de autorização")
+	ErrPSPAuthorizationNotFound = errors.New("autorização não encontrada no PSP")
+	ErrPSPInvalidSignature      = errors.New("assinatura do webhook do PSP inválida")
+	ErrPSPUnknownEvent          = errors.New("evento do PSP desconhecido")
+)
+
+// PSPDeclineError detalha a recusa com o código de resposta ISO 8583 do emissor
+type PSPDeclineError struct {
+	Code   string
+	Reason string
+}
+
+func (e *PSPDeclineError) Error() string {
+	return fmt.Sprintf("%v: %s (%s)", ErrPSPDeclined, e.Reason, e.Code)
+}
+
+func (e *PSPDeclineError) Unwrap() error {
+	return ErrPSPDeclined
+}
+
+// PSPChallengeError indica para onde redirecionar o pagador para o desafio 3DS. O pagamento é
+// reenviado com ThreeDSData["authentication_status"] depois do desafio
+type PSPChallengeError struct {
+	Challenge PSP3DSChallenge
+}
+
+func (e *PSPChallengeError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrPSP3DSChallengeRequired, e.Challenge.ACSURL)
+}
+
+func (e *PSPChallengeError) Unwrap() error {
+	return ErrPSP3DSChallengeRequired
+}
+
+// PSP3DSChallenge identifica o desafio 3DS pedido pelo emissor
+type PSP3DSChallenge struct {
+	ACSURL               string `json:"acs_url"`
+	ThreeDSServerTransID string `json:"three_ds_server_trans_id"`
+}
+
+// PSPAuthorizationRequest é o pedido de autorização enviado ao PSP
+type PSPAuthorizationRequest struct {
+	TransactionID        string  `json:"transaction_id"`
+	MerchantID           string  `json:"merchant_id"`
+	PaymentMethod        string  `json:"payment_method"`
+	Amount               float64 `json:"amount"`
+	Currency             string  `json:"currency"`
+	CardNumber           string  `json:"card_number,omitempty"`
+	ThreeDSStatus        string  `json:"three_ds_status,omitempty"` // authentication_status do 3DS (Y, A, U)
+	ThreeDSServerTransID string  `json:"three_ds_server_trans_id,omitempty"`
+	NotificationURL      string  `json:"notification_url,omitempty"` // Destino do webhook das autorizações pendentes
+
+	StoredCredential *StoredCredentialIndicator `json:"stored_credential,omitempty"` // Indicadores CIT/MIT do cartão armazenado
+}
+
+// PSPAuthorizationResponse é a resposta do PSP a um pedido de autorização
+type PSPAuthorizationResponse struct {
+	Reference     string           `json:"reference"`
+	TransactionID string           `json:"transaction_id"`
+	Status        string           `json:"status"`
+	ResponseCode  string           `json:"response_code,omitempty"`
+	Reason        string           `json:"reason,omitempty"`
+	Challenge     *PSP3DSChallenge `json:"challenge,omitempty"`
+
+	NetworkTransactionID string `json:"network_transaction_id,omitempty"` // ID na rede, referência das MIT seguintes
+}
+
+// PSPWebhookEvent é o desfecho de uma autorização pendente enviado pelo PSP
+type PSPWebhookEvent struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Reference     string    `json:"reference"`
+	TransactionID string    `json:"transaction_id"`
+	Status        string    `json:"status"`
+	ResponseCode  string    `json:"response_code,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// PSPAuthorization acompanha no gateway uma autorização aprovada ou pendente no PSP
+type PSPAuthorization struct {
+	Reference     string    `json:"reference" db:"reference"`
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	MerchantID    string    `json:"merchant_id" db:"merchant_id"`
+	UserID        string    `json:"-" db:"user_id"`
+	PaymentMethod string    `json:"payment_method" db:"payment_method"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	RegionCode    string    `json:"region_code" db:"region_code"`
+	Status        string    `json:"status" db:"status"`
+	ResponseCode  string    `json:"response_code,omitempty" db:"response_code"`
+	Reason        string    `json:"reason,omitempty" db:"reason"`
+	Asynchronous  bool      `json:"asynchronous" db:"asynchronous"` // Recebida pendente; o desfecho chega por webhook
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	NetworkTransactionID string `json:"network_transaction_id,omitempty" db:"network_transaction_id"`
+}
+
+// PSPConfig contém as configurações da integração com o PSP
+type PSPConfig struct {
+	// API de autorizações do PSP (ex.: simulador em "http://localhost:8099")
+	Endpoint string `json:"endpoint"`
+
+	// Chave enviada ao PSP no cabeçalho Authorization
+	APIKey string `json:"-"`
+
+	// Endereço público de /psp/webhooks indicado ao PSP nas autorizações
+	NotificationURL string `json:"notification_url"`
+
+	// Segredo HMAC partilhado com o PSP para assinar os webhooks
+	WebhookSecret string `json:"-"`
+
+	Timeout time.Duration `json:"timeout"`
+
+	// Resilience sobrepõe Timeout e define as novas tentativas da autorização
+	Resilience resilience.Policy `json:"resilience"`
+
+	// Sandbox indica o PSP dos comerciantes em modo sandbox; as notificações aos comerciantes
+	// indicam sandbox=true. Definido por NewSandboxService
+	Sandbox bool `json:"-"`
+}
@@ -0,0 +1,62 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresPSPAuthorizationStore implementa PSPAuthorizationStore para PostgreSQL
+type PostgresPSPAuthorizationStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresPSPAuthorizationStore cria uma nova instância de PostgresPSPAuthorizationStore
+func NewPostgresPSPAuthorizationStore(db *sqlx.DB) *PostgresPSPAuthorizationStore {
+	return &PostgresPSPAuthorizationStore{db: db}
+}
+
+// SavePSPAuthorization grava a autorização, substituindo o estado anterior
+func (r *PostgresPSPAuthorizationStore) SavePSPAuthorization(ctx context.Context, authorization *PSPAuthorization) error {
+	query := `
+		INSERT INTO payment_gateway.psp_authorizations (
+			reference, tenant_id, transaction_id, merchant_id, user_id, payment_method, amount, currency,
+			region_code, status, response_code, reason, asynchronous, created_at, updated_at, network_transaction_id
+		) VALUES (
+			:reference, :tenant_id, :transaction_id, :merchant_id, :user_id, :payment_method, :amount, :currency,
+			:region_code, :status, :response_code, :reason, :asynchronous, :created_at, :updated_at, :network_transaction_id
+		)
+		ON CONFLICT (reference) DO UPDATE SET
+			status = EXCLUDED.status,
+			response_code = EXCLUDED.response_code,
+			reason = EXCLUDED.reason,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, authorization); err != nil {
+		return fmt.Errorf("falha ao gravar autorização do PSP: %w", err)
+	}
+
+	return nil
+}
+
+// GetPSPAuthorization recupera a autorização pela referência do PSP
+func (r *PostgresPSPAuthorizationStore) GetPSPAuthorization(ctx context.Context, reference string) (*PSPAuthorization, error) {
+	var authorization PSPAuthorization
+	query := `
+		SELECT reference, tenant_id, transaction_id, merchant_id, user_id, payment_method, amount, currency,
+			region_code, status, response_code, reason, asynchronous, created_at, updated_at, network_transaction_id
+		FROM payment_gateway.psp_authorizations
+		WHERE reference = $1
+	`
+	if err := r.db.GetContext(ctx, &authorization, query, reference); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPSPAuthorizationNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar autorização do PSP: %w", err)
+	}
+	return &authorization, nil
+}
@@ -0,0 +1,260 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// PSPService autoriza os pagamentos no PSP e concilia os webhooks das autorizações pendentes
+type PSPService struct {
+	config   PSPConfig
+	store    PSPAuthorizationStore
+	client   PSPClient
+	webhooks *WebhookDeliveryService
+
+	// Serializa a conclusão das autorizações pendentes
+	mutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewPSPService cria o serviço de autorização no PSP
+func NewPSPService(config PSPConfig, store PSPAuthorizationStore, client PSPClient) (*PSPService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-psp",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if client == nil {
+		client = NewHTTPPSPClient(config)
+	}
+
+	service := &PSPService{
+		config:          config,
+		store:           store,
+		client:          client,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+
+	service.logger.Info("Serviço de autorização no PSP inicializado", "endpoint", config.Endpoint)
+	return service, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes do desfecho das autorizações pendentes
+func (s *PSPService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.webhooks = webhooks
+}
+
+// Authorize autoriza o pagamento no PSP e retorna a autorização aprovada ou pendente. As recusas
+// retornam *PSPDeclineError e os desafios 3DS *PSPChallengeError
+func (s *PSPService) Authorize(ctx context.Context, req *PaymentRequest) (*PSPAuthorization, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "PSPService.Authorize")
+	defer span.End()
+
+	started := s.now()
+	response, err := s.client.Authorize(ctx, s.authorizationRequest(req))
+	s.metricsRecorder.HistogramObserve("payment_psp_latency_ms", float64(s.now().Sub(started).Milliseconds()), map[string]string{
+		"payment_method": req.PaymentMethod,
+	})
+	if err != nil {
+		span.RecordError(err)
+		s.metricsRecorder.CounterInc("payment_psp_errors_total", map[string]string{
+			"payment_method": req.PaymentMethod,
+		})
+		return nil, err
+	}
+	s.metricsRecorder.CounterInc("payment_psp_authorizations_total", map[string]string{
+		"payment_method": req.PaymentMethod,
+		"status":         response.Status,
+	})
+
+	switch response.Status {
+	case PSPStatusApproved, PSPStatusPending:
+		now := s.now()
+		authorization := &PSPAuthorization{
+			Reference:     response.Reference,
+			TenantID:      req.TenantID,
+			TransactionID: req.TransactionID,
+			MerchantID:    req.MerchantID,
+			UserID:        req.UserID,
+			PaymentMethod: req.PaymentMethod,
+			Amount:        req.Amount,
+			Currency:      req.Currency,
+			RegionCode:    req.RegionCode,
+			Status:        response.Status,
+			ResponseCode:  response.ResponseCode,
+			Reason:        response.Reason,
+			Asynchronous:  response.Status == PSPStatusPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+
+			NetworkTransactionID: response.NetworkTransactionID,
+		}
+		if err := s.store.SavePSPAuthorization(ctx, authorization); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		s.logger.InfoWithContext(ctx, "Autorização do PSP recebida",
+			"tenant_id", req.TenantID,
+			"transaction_id", req.TransactionID,
+			"psp_reference", response.Reference,
+			"status", response.Status)
+		return authorization, nil
+
+	case PSPStatusDeclined:
+		// Evento de segurança: recusa do emissor
+		s.logger.WarnWithContext(ctx, "Pagamento recusado pelo PSP",
+			"tenant_id", req.TenantID,
+			"transaction_id", req.TransactionID,
+			"response_code", response.ResponseCode,
+			"reason", response.Reason)
+		return nil, &PSPDeclineError{Code: response.ResponseCode, Reason: response.Reason}
+
+	case PSPStatusChallengeRequired:
+		if response.Challenge == nil {
+			return nil, fmt.Errorf("desafio 3DS sem endereço do ACS na resposta do PSP")
+		}
+		s.logger.InfoWithContext(ctx, "Desafio 3DS exigido pelo emissor",
+			"tenant_id", req.TenantID,
+			"transaction_id", req.TransactionID,
+			"three_ds_server_trans_id", response.Challenge.ThreeDSServerTransID)
+		return nil, &PSPChallengeError{Challenge: *response.Challenge}
+
+	default:
+		return nil, fmt.Errorf("estado de autorização desconhecido do PSP: %s", response.Status)
+	}
+}
+
+// CompleteAuthorization aplica o desfecho de uma autorização pendente e notifica o comerciante
+// Webhooks repetidos de uma autorização já concluída retornam a autorização sem alterações
+func (s *PSPService) CompleteAuthorization(ctx context.Context, event PSPWebhookEvent) (*PSPAuthorization, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "PSPService.CompleteAuthorization")
+	defer span.End()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	authorization, err := s.store.GetPSPAuthorization(ctx, event.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if authorization.Status != PSPStatusPending {
+		return authorization, nil
+	}
+
+	switch event.EventType {
+	case PSPEventAuthorizationApproved:
+		authorization.Status = PSPStatusApproved
+	case PSPEventAuthorizationDeclined:
+		authorization.Status = PSPStatusDeclined
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPSPUnknownEvent, event.EventType)
+	}
+	authorization.ResponseCode = event.ResponseCode
+	authorization.Reason = event.Reason
+	authorization.UpdatedAt = s.now()
+
+	if err := s.store.SavePSPAuthorization(ctx, authorization); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_psp_authorizations_total", map[string]string{
+		"payment_method": authorization.PaymentMethod,
+		"status":         authorization.Status,
+	})
+
+	// Evento de auditoria do desfecho da autorização pendente
+	s.logger.InfoWithContext(ctx, "Autorização pendente concluída pelo PSP",
+		"tenant_id", authorization.TenantID,
+		"transaction_id", authorization.TransactionID,
+		"psp_reference", authorization.Reference,
+		"event_id", event.EventID,
+		"status", authorization.Status)
+
+	if authorization.Status == PSPStatusDeclined {
+		cause := &PSPDeclineError{Code: authorization.ResponseCode, Reason: authorization.Reason}
+		s.notify(ctx, authorization, WebhookEventTransactionFailed, cause.Error())
+	} else {
+		s.notify(ctx, authorization, WebhookEventTransactionCompleted, "")
+	}
+	return authorization, nil
+}
+
+// VerifyWebhookSignature valida o cabeçalho X-PSP-Signature de um webhook do PSP
+func (s *PSPService) VerifyWebhookSignature(header string, body []byte) error {
+	if s.config.WebhookSecret == "" {
+		return fmt.Errorf("%w: segredo de webhooks não configurado", ErrPSPInvalidSignature)
+	}
+
+	if err := verifyPSPSignature(s.config.WebhookSecret, header, body, s.now(), pspSignatureTolerance); err != nil {
+		return fmt.Errorf("%w: %v", ErrPSPInvalidSignature, err)
+	}
+	return nil
+}
+
+// authorizationRequest monta o pedido de autorização a partir do pagamento
+func (s *PSPService) authorizationRequest(req *PaymentRequest) *PSPAuthorizationRequest {
+	request := &PSPAuthorizationRequest{
+		TransactionID:   req.TransactionID,
+		MerchantID:      req.MerchantID,
+		PaymentMethod:   req.PaymentMethod,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		NotificationURL: s.config.NotificationURL,
+	}
+	request.CardNumber, _ = req.PaymentDetails["card_number"].(string)
+	request.ThreeDSStatus, _ = req.ThreeDSData["authentication_status"].(string)
+	request.ThreeDSServerTransID, _ = req.ThreeDSData["three_ds_server_trans_id"].(string)
+	if req.StoredCredential != nil {
+		indicator := *req.StoredCredential
+		request.StoredCredential = &indicator
+	}
+	return request
+}
+
+// notify agenda a notificação ao comerciante do desfecho da autorização pendente
+func (s *PSPService) notify(ctx context.Context, authorization *PSPAuthorization, eventType, reason string) {
+	if s.webhooks == nil {
+		return
+	}
+
+	status := TransactionStatusApproved
+	if eventType == WebhookEventTransactionFailed {
+		status = TransactionStatusDenied
+	}
+	if _, err := s.webhooks.Enqueue(ctx, WebhookEvent{
+		EventType:     eventType,
+		TenantID:      authorization.TenantID,
+		MerchantID:    authorization.MerchantID,
+		TransactionID: authorization.TransactionID,
+		Status:        status,
+		PaymentMethod: authorization.PaymentMethod,
+		Amount:        authorization.Amount,
+		Currency:      authorization.Currency,
+		ProcessorRef:  authorization.Reference,
+		Reason:        reason,
+		Market:        authorization.RegionCode,
+		Sandbox:       s.config.Sandbox,
+	}); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao agendar notificação da autorização do PSP",
+			"psp_reference", authorization.Reference,
+			"transaction_id", authorization.TransactionID,
+			"error", err.Error())
+	}
+}
@@ -0,0 +1,247 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+func newTestPSPService(t *testing.T, config PSPConfig) (*PSPService, *InMemoryPSPAuthorizationStore) {
+	t.Helper()
+
+	if config.WebhookSecret == "" {
+		config.WebhookSecret = testPSPWebhookSecret
+	}
+	config.Resilience = resilience.Policy{MaxAttempts: 1}
+	store := NewInMemoryPSPAuthorizationStore()
+	service, err := NewPSPService(config, store, nil)
+	require.NoError(t, err)
+	return service, store
+}
+
+// testPSPRequest cria um pagamento com cartão no mercado EUA
+func testPSPRequest(requestID, cardNumber string, amount float64) *PaymentRequest {
+	req := testRiskRequest(RegionUSA, "USD", amount)
+	req.RequestID = requestID
+	req.TransactionID = "tx-" + requestID
+	req.PaymentDetails = map[string]interface{}{"card_number": cardNumber}
+	return req
+}
+
+func TestProcessPaymentAuthorizedByPSP(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{})
+
+	t.Run("aprovado", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, store := newTestPSPService(t, PSPConfig{Endpoint: server.URL})
+		connector.SetPSPService(service)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("approved", "4111111111111111", 120))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		require.NotEmpty(t, response.AuthorizationID)
+
+		authorization, err := store.GetPSPAuthorization(ctx, response.AuthorizationID)
+		require.NoError(t, err)
+		assert.Equal(t, PSPStatusApproved, authorization.Status)
+		assert.Equal(t, "tx-approved", authorization.TransactionID)
+		assert.False(t, authorization.Asynchronous)
+	})
+
+	t.Run("recusado pelo emissor", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _ := newTestPSPService(t, PSPConfig{Endpoint: server.URL})
+		connector.SetPSPService(service)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("declined", PSPSimulatorCardInsufficientFunds, 120))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "psp_recusado", response.StatusCode)
+		assert.Equal(t, "51", response.Metadata["psp_response_code"])
+		assert.Empty(t, response.AuthorizationID)
+	})
+
+	t.Run("meios de pagamento com fluxo próprio não chegam ao PSP", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _ := newTestPSPService(t, PSPConfig{Endpoint: "http://psp.invalid"})
+		connector.SetPSPService(service)
+
+		req := testPSPRequest("mobile-money", "", 120)
+		req.PaymentMethod = PaymentMethodMobileMoney
+		response, err := connector.ProcessPayment(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+	})
+}
+
+func TestProcessPaymentPSP3DSChallenge(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{})
+	connector, _ := newTestConnector(t)
+	service, _ := newTestPSPService(t, PSPConfig{Endpoint: server.URL})
+	connector.SetPSPService(service)
+
+	response, err := connector.ProcessPayment(ctx, testPSPRequest("3ds", PSPSimulatorCard3DSChallenge, 320))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusChallenged, response.Status)
+	assert.True(t, response.ChallengeRequired)
+	require.NotNil(t, response.ChallengeDetails)
+	assert.Equal(t, "3ds", response.ChallengeDetails.ChallengeType)
+	assert.Contains(t, response.ChallengeDetails.VerificationURL, "https://acs.psp-simulator.innovabiz.test/challenge/")
+
+	// Desafio concluído pelo pagador: o pagamento é reenviado com o resultado da autenticação
+	req := testPSPRequest("3ds", PSPSimulatorCard3DSChallenge, 320)
+	req.ThreeDSData = map[string]interface{}{
+		"authentication_status":    "Y",
+		"three_ds_server_trans_id": response.ChallengeDetails.ChallengeID,
+	}
+	response, err = connector.ProcessPayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusApproved, response.Status)
+	assert.NotEmpty(t, response.AuthorizationID)
+}
+
+func TestProcessPaymentPSPFailures(t *testing.T) {
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{TimeoutDelay: 5 * time.Second})
+
+	tests := []struct {
+		name       string
+		cardNumber string
+		amount     float64
+	}{
+		{"tempo esgotado", "4111111111111111", 75.91},
+		{"emissor indisponível", "4111111111111111", 75.92},
+		{"erro de processamento", PSPSimulatorCardProcessingError, 75.00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, _ := newTestConnector(t)
+			service, _ := newTestPSPService(t, PSPConfig{Endpoint: server.URL, Timeout: 200 * time.Millisecond})
+			connector.SetPSPService(service)
+
+			response, err := connector.ProcessPayment(context.Background(), testPSPRequest("failure", tt.cardNumber, tt.amount))
+			require.NoError(t, err)
+			assert.Equal(t, TransactionStatusError, response.Status)
+			assert.Equal(t, "psp_indisponivel", response.StatusCode)
+		})
+	}
+}
+
+func TestProcessPaymentPSPPendingCompletedByWebhook(t *testing.T) {
+	tests := []struct {
+		name          string
+		amount        float64
+		wantStatus    string
+		wantEventType string
+	}{
+		{"aprovado por webhook", 60.93, PSPStatusApproved, WebhookEventTransactionCompleted},
+		{"recusado por webhook", 60.94, PSPStatusDeclined, WebhookEventTransactionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			router := mux.NewRouter()
+			gateway := httptest.NewServer(router)
+			defer gateway.Close()
+
+			simulator, server := newTestPSPSimulator(t, PSPSimulatorConfig{})
+			service, store := newTestPSPService(t, PSPConfig{
+				Endpoint:        server.URL,
+				NotificationURL: gateway.URL + "/psp/webhooks",
+			})
+			NewPSPHandler(service).RegisterRoutes(router)
+			webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+				WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+			service.SetWebhookDeliveryService(webhooks)
+
+			connector, _ := newTestConnector(t)
+			connector.SetPSPService(service)
+			connector.SetWebhookDeliveryService(webhooks)
+
+			response, err := connector.ProcessPayment(ctx, testPSPRequest("pending", "4111111111111111", tt.amount))
+			require.NoError(t, err)
+			assert.Equal(t, TransactionStatusPending, response.Status)
+			assert.Equal(t, "psp_pendente", response.StatusCode)
+
+			simulator.Wait()
+			require.Len(t, simulator.Webhooks(), 1)
+			authorization, err := store.GetPSPAuthorization(ctx, response.AuthorizationID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, authorization.Status)
+			assert.True(t, authorization.Asynchronous)
+
+			list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+			require.NoError(t, err)
+			var eventTypes []string
+			for _, delivery := range list {
+				eventTypes = append(eventTypes, delivery.Event.EventType)
+			}
+			assert.ElementsMatch(t, []string{WebhookEventTransactionProcessing, tt.wantEventType}, eventTypes)
+		})
+	}
+}
+
+func TestPSPHandlerWebhook(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestPSPService(t, PSPConfig{})
+	require.NoError(t, store.SavePSPAuthorization(ctx, &PSPAuthorization{
+		Reference:     "SIM-1",
+		TenantID:      "tenant-1",
+		TransactionID: "tx-1",
+		MerchantID:    "merchant-1",
+		Status:        PSPStatusPending,
+		Asynchronous:  true,
+	}))
+
+	router := mux.NewRouter()
+	NewPSPHandler(service).RegisterRoutes(router)
+	serve := func(event PSPWebhookEvent, secret string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(event)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/psp/webhooks", bytes.NewReader(body))
+		req.Header.Set(PSPSignatureHeader, SignPSPPayload(secret, time.Now(), body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("assinatura inválida", func(t *testing.T) {
+		rec := serve(PSPWebhookEvent{Reference: "SIM-1", EventType: PSPEventAuthorizationApproved}, "outro-segredo")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("autorização desconhecida", func(t *testing.T) {
+		rec := serve(PSPWebhookEvent{Reference: "SIM-2", EventType: PSPEventAuthorizationApproved}, testPSPWebhookSecret)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("evento desconhecido", func(t *testing.T) {
+		rec := serve(PSPWebhookEvent{Reference: "SIM-1", EventType: "authorization.captured"}, testPSPWebhookSecret)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("webhook repetido não altera a autorização concluída", func(t *testing.T) {
+		rec := serve(PSPWebhookEvent{Reference: "SIM-1", EventType: PSPEventAuthorizationApproved, ResponseCode: "00"}, testPSPWebhookSecret)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(PSPWebhookEvent{Reference: "SIM-1", EventType: PSPEventAuthorizationDeclined, ResponseCode: "05"}, testPSPWebhookSecret)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var authorization PSPAuthorization
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&authorization))
+		assert.Equal(t, PSPStatusApproved, authorization.Status)
+		assert.Equal(t, "00", authorization.ResponseCode)
+	})
+}
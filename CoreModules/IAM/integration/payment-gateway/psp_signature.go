@@ -0,0 +1,52 @@
+package paymentgateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignPSPPayload calcula o cabeçalho "t=<unix>,v1=<hmac>" das notificações trocadas com os PSPs
+// O destinatário valida recalculando o HMAC-SHA256 de "<t>.<corpo>" com o segredo partilhado
+func SignPSPPayload(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// verifyPSPSignature valida um cabeçalho calculado como em SignPSPPayload
+func verifyPSPSignature(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return errors.New("cabeçalho malformado")
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	if skew := now.Sub(signedAt); skew > tolerance || skew < -tolerance {
+		return errors.New("carimbo temporal fora da tolerância")
+	}
+
+	expected := SignPSPPayload(secret, signedAt, body)
+	if !hmac.Equal([]byte(expected), []byte(fmt.Sprintf("t=%s,v1=%s", timestamp, signature))) {
+		return errors.New("assinatura não confere")
+	}
+	return nil
+}
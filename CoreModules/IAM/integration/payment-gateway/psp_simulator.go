@@ -0,0 +1,347 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+)
+
+// Simulador determinístico de PSP para testes de integração e ambientes de homologação.
+// O desfecho de cada autorização depende apenas do número do cartão e dos cêntimos do valor:
+//
+//	Cartão 4000000000000002  recusado, 05 do_not_honor
+//	Cartão 4000000000009995  recusado, 51 insufficient_funds
+//	Cartão 4000000000000069  recusado, 54 expired_card
+//	Cartão 4000000000003220  desafio 3DS; aprovado quando reenviado com three_ds_status Y ou A
+//	Cartão 4000000000000119  HTTP 500, 96 processing_error
+//	Valor  x,05              recusado, 05 do_not_honor (qualquer meio de pagamento)
+//	Valor  x,51              recusado, 51 insufficient_funds (qualquer meio de pagamento)
+//	Valor  x,91              sem resposta durante TimeoutDelay (tempo esgotado no cliente)
+//	Valor  x,92              HTTP 503, 91 issuer_unavailable
+//	Valor  x,93              pendente; webhook authorization.approved após WebhookDelay
+//	Valor  x,94              pendente; webhook authorization.declined (05) após WebhookDelay
+//
+// Os restantes pedidos são aprovados. As referências derivam do ID da transação e os pedidos
+// repetidos com o mesmo ID recebem a mesma resposta
+const (
+	PSPSimulatorCardDoNotHonor        = "4000000000000002"
+	PSPSimulatorCardInsufficientFunds = "4000000000009995"
+	PSPSimulatorCardExpired           = "4000000000000069"
+	PSPSimulatorCard3DSChallenge      = "4000000000003220"
+	PSPSimulatorCardProcessingError   = "4000000000000119"
+)
+
+// Valores padrão do simulador de PSP
+const (
+	DefaultPSPSimulatorTimeoutDelay = 30 * time.Second
+	DefaultPSPSimulatorWebhookDelay = 100 * time.Millisecond
+
+	pspSimulatorWebhookTimeout = 10 * time.Second
+	pspSimulatorACSURL         = "https://acs.psp-simulator.innovabiz.test/challenge"
+)
+
+// PSPSimulatorConfig contém as configurações do simulador de PSP
+type PSPSimulatorConfig struct {
+	// Chave exigida no cabeçalho Authorization; vazio aceita qualquer pedido
+	APIKey string
+
+	// Segredo HMAC dos webhooks (cabeçalho X-PSP-Signature)
+	WebhookSecret string
+
+	// Destino dos webhooks quando o pedido não indica notification_url
+	WebhookURL string
+
+	// Espera dos valores x,91 e antes do webhook das autorizações pendentes
+	TimeoutDelay time.Duration
+	WebhookDelay time.Duration
+}
+
+// pspSimulatorOutcome é o desfecho determinado para um pedido de autorização
+type pspSimulatorOutcome struct {
+	httpStatus int
+	response   PSPAuthorizationResponse
+	webhook    *PSPWebhookEvent
+	delay      bool
+}
+
+// PSPSimulator emula a API de autorizações de um PSP (POST /v1/authorizations e
+// GET /v1/authorizations/{reference}) e envia os webhooks das autorizações pendentes
+type PSPSimulator struct {
+	config PSPSimulatorConfig
+	client *http.Client
+	logger logging.Logger
+
+	mutex          sync.Mutex
+	authorizations map[string]*pspSimulatorOutcome // Por ID da transação
+	references     map[string]string               // ID da transação por referência
+	webhooks       []PSPWebhookEvent               // Webhooks enviados, por ordem
+	wg             sync.WaitGroup
+}
+
+// NewPSPSimulator cria o simulador de PSP
+func NewPSPSimulator(config PSPSimulatorConfig) (*PSPSimulator, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-psp-simulator",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.TimeoutDelay <= 0 {
+		config.TimeoutDelay = DefaultPSPSimulatorTimeoutDelay
+	}
+	if config.WebhookDelay <= 0 {
+		config.WebhookDelay = DefaultPSPSimulatorWebhookDelay
+	}
+
+	return &PSPSimulator{
+		config:         config,
+		client:         &http.Client{Timeout: pspSimulatorWebhookTimeout},
+		logger:         obsAdapter.Logger(),
+		authorizations: make(map[string]*pspSimulatorOutcome),
+		references:     make(map[string]string),
+	}, nil
+}
+
+// ServeHTTP atende a API de autorizações do simulador
+func (s *PSPSimulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+s.config.APIKey {
+		respondWithError(w, http.StatusUnauthorized, "unauthorized", "Credenciais inválidas")
+		return
+	}
+	if r.URL.Path != "/v1/authorizations" && !strings.HasPrefix(r.URL.Path, "/v1/authorizations/") {
+		respondWithError(w, http.StatusNotFound, "not_found", "Recurso não encontrado")
+		return
+	}
+
+	reference := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/authorizations"), "/")
+	switch {
+	case reference == "" && r.Method == http.MethodPost:
+		s.authorize(w, r)
+	case reference != "" && r.Method == http.MethodGet:
+		s.getAuthorization(w, reference)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Método não permitido")
+	}
+}
+
+// Webhooks retorna os webhooks enviados pelo simulador
+func (s *PSPSimulator) Webhooks() []PSPWebhookEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]PSPWebhookEvent(nil), s.webhooks...)
+}
+
+// Wait aguarda o envio dos webhooks agendados
+func (s *PSPSimulator) Wait() {
+	s.wg.Wait()
+}
+
+// authorize decide o desfecho do pedido e agenda o webhook das autorizações pendentes
+func (s *PSPSimulator) authorize(w http.ResponseWriter, r *http.Request) {
+	var request PSPAuthorizationRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&request); err != nil || request.TransactionID == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Pedido de autorização inválido")
+		return
+	}
+
+	s.mutex.Lock()
+	outcome, repeated := s.authorizations[request.TransactionID]
+	// Um pedido repetido após o desafio 3DS é decidido de novo com o resultado da autenticação
+	if !repeated || outcome.response.Status == PSPStatusChallengeRequired {
+		outcome = s.decide(request)
+		s.authorizations[request.TransactionID] = outcome
+		s.references[outcome.response.Reference] = request.TransactionID
+		repeated = false
+	}
+	s.mutex.Unlock()
+
+	if outcome.delay {
+		select {
+		case <-time.After(s.config.TimeoutDelay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if outcome.webhook != nil && !repeated {
+		destination := request.NotificationURL
+		if destination == "" {
+			destination = s.config.WebhookURL
+		}
+		s.scheduleWebhook(destination, *outcome.webhook)
+	}
+
+	respondWithJSON(w, outcome.httpStatus, outcome.response)
+}
+
+// getAuthorization retorna o estado atual de uma autorização
+func (s *PSPSimulator) getAuthorization(w http.ResponseWriter, reference string) {
+	s.mutex.Lock()
+	transactionID, exists := s.references[reference]
+	var response PSPAuthorizationResponse
+	if exists {
+		response = s.authorizations[transactionID].response
+	}
+	s.mutex.Unlock()
+
+	if !exists {
+		respondWithError(w, http.StatusNotFound, "not_found", "Autorização não encontrada")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// decide aplica os cartões e valores mágicos ao pedido
+func (s *PSPSimulator) decide(request PSPAuthorizationRequest) *pspSimulatorOutcome {
+	sum := sha256.Sum256([]byte(request.TransactionID))
+	reference := "SIM-" + strings.ToUpper(hex.EncodeToString(sum[:8]))
+	outcome := &pspSimulatorOutcome{
+		httpStatus: http.StatusOK,
+		response: PSPAuthorizationResponse{
+			Reference:     reference,
+			TransactionID: request.TransactionID,
+			Status:        PSPStatusApproved,
+			ResponseCode:  "00",
+			Reason:        "approved",
+		},
+	}
+	decline := func(code, reason string) *pspSimulatorOutcome {
+		outcome.response.Status = PSPStatusDeclined
+		outcome.response.ResponseCode = code
+		outcome.response.Reason = reason
+		return outcome
+	}
+	failure := func(status int, code, reason string) *pspSimulatorOutcome {
+		outcome.httpStatus = status
+		outcome.response.Status = ""
+		outcome.response.ResponseCode = code
+		outcome.response.Reason = reason
+		return outcome
+	}
+
+	// As transações com cartão armazenado recebem o ID na rede; as MIT têm de referir a CIT inicial
+	// e, sem o titular presente, não recebem desafio 3DS
+	merchantInitiated := false
+	if credential := request.StoredCredential; credential != nil {
+		merchantInitiated = credential.Initiator == StoredCredentialInitiatorMerchant
+		if merchantInitiated && credential.NetworkTransactionID == "" {
+			return decline("57", "transaction_not_permitted")
+		}
+		outcome.response.NetworkTransactionID = "NTID-" + strings.ToUpper(hex.EncodeToString(sum[8:16]))
+	}
+
+	switch request.CardNumber {
+	case PSPSimulatorCardDoNotHonor:
+		return decline("05", "do_not_honor")
+	case PSPSimulatorCardInsufficientFunds:
+		return decline("51", "insufficient_funds")
+	case PSPSimulatorCardExpired:
+		return decline("54", "expired_card")
+	case PSPSimulatorCardProcessingError:
+		return failure(http.StatusInternalServerError, "96", "processing_error")
+	case PSPSimulatorCard3DSChallenge:
+		if request.ThreeDSStatus != "Y" && request.ThreeDSStatus != "A" && !merchantInitiated {
+			outcome.response.Status = PSPStatusChallengeRequired
+			outcome.response.ResponseCode = "1A"
+			outcome.response.Reason = "authentication_required"
+			outcome.response.Challenge = &PSP3DSChallenge{
+				ACSURL:               pspSimulatorACSURL + "/" + reference,
+				ThreeDSServerTransID: "3DS-" + reference,
+			}
+			return outcome
+		}
+	}
+
+	cents := int(math.Round(request.Amount*100)) % 100
+	switch cents {
+	case 5:
+		return decline("05", "do_not_honor")
+	case 51:
+		return decline("51", "insufficient_funds")
+	case 91:
+		outcome.delay = true
+	case 92:
+		return failure(http.StatusServiceUnavailable, "91", "issuer_unavailable")
+	case 93, 94:
+		outcome.response.Status = PSPStatusPending
+		outcome.response.ResponseCode = ""
+		outcome.response.Reason = "pending"
+		event := PSPWebhookEvent{
+			EventID:       "evt_" + reference,
+			EventType:     PSPEventAuthorizationApproved,
+			Reference:     reference,
+			TransactionID: request.TransactionID,
+			Status:        PSPStatusApproved,
+			ResponseCode:  "00",
+			Reason:        "approved",
+			Amount:        request.Amount,
+			Currency:      request.Currency,
+		}
+		if cents == 94 {
+			event.EventType = PSPEventAuthorizationDeclined
+			event.Status = PSPStatusDeclined
+			event.ResponseCode = "05"
+			event.Reason = "do_not_honor"
+		}
+		outcome.webhook = &event
+	}
+	return outcome
+}
+
+// scheduleWebhook envia o webhook assinado após WebhookDelay
+func (s *PSPSimulator) scheduleWebhook(destination string, event PSPWebhookEvent) {
+	if destination == "" {
+		s.logger.Warn("Webhook do simulador sem destino", "reference", event.Reference)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		time.Sleep(s.config.WebhookDelay)
+
+		event.OccurredAt = time.Now().UTC()
+		body, err := json.Marshal(event)
+		if err != nil {
+			s.logger.Error("Falha ao serializar webhook do simulador", "error", err.Error())
+			return
+		}
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, destination, bytes.NewReader(body))
+		if err != nil {
+			s.logger.Error("Destino de webhook inválido", "url", destination, "error", err.Error())
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(PSPSignatureHeader, SignPSPPayload(s.config.WebhookSecret, time.Now(), body))
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			s.logger.Warn("Falha ao enviar webhook do simulador", "reference", event.Reference, "error", err.Error())
+			return
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		s.mutex.Lock()
+		s.webhooks = append(s.webhooks, event)
+		s.mutex.Unlock()
+		s.logger.Info("Webhook do simulador enviado",
+			"reference", event.Reference,
+			"event_type", event.EventType,
+			"status_code", resp.StatusCode)
+	}()
+}
@@ -0,0 +1,165 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+const testPSPWebhookSecret = "segredo-webhooks-psp-de-teste"
+
+// newTestPSPSimulator inicia o simulador num servidor HTTP local
+func newTestPSPSimulator(t *testing.T, config PSPSimulatorConfig) (*PSPSimulator, *httptest.Server) {
+	t.Helper()
+
+	if config.WebhookSecret == "" {
+		config.WebhookSecret = testPSPWebhookSecret
+	}
+	if config.WebhookDelay == 0 {
+		config.WebhookDelay = 10 * time.Millisecond
+	}
+	simulator, err := NewPSPSimulator(config)
+	require.NoError(t, err)
+	server := httptest.NewServer(simulator)
+	t.Cleanup(func() {
+		server.Close()
+		simulator.Wait()
+	})
+	return simulator, server
+}
+
+// newTestPSPClient cria o cliente do simulador sem novas tentativas
+func newTestPSPClient(endpoint, apiKey string, timeout time.Duration) *HTTPPSPClient {
+	return NewHTTPPSPClient(PSPConfig{
+		Endpoint:   endpoint,
+		APIKey:     apiKey,
+		Timeout:    timeout,
+		Resilience: resilience.Policy{MaxAttempts: 1},
+	})
+}
+
+func TestPSPSimulatorMagicValues(t *testing.T) {
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{TimeoutDelay: 5 * time.Second})
+	client := newTestPSPClient(server.URL, "", 200*time.Millisecond)
+
+	tests := []struct {
+		name       string
+		cardNumber string
+		threeDS    string
+		amount     float64
+		wantStatus string
+		wantCode   string
+		wantErr    error
+	}{
+		{"aprovado", "4111111111111111", "", 100.00, PSPStatusApproved, "00", nil},
+		{"cartão recusado", PSPSimulatorCardDoNotHonor, "", 100.00, PSPStatusDeclined, "05", nil},
+		{"saldo insuficiente", PSPSimulatorCardInsufficientFunds, "", 100.00, PSPStatusDeclined, "51", nil},
+		{"cartão expirado", PSPSimulatorCardExpired, "", 100.00, PSPStatusDeclined, "54", nil},
+		{"desafio 3DS", PSPSimulatorCard3DSChallenge, "", 100.00, PSPStatusChallengeRequired, "1A", nil},
+		{"desafio 3DS concluído", PSPSimulatorCard3DSChallenge, "Y", 100.00, PSPStatusApproved, "00", nil},
+		{"erro de processamento", PSPSimulatorCardProcessingError, "", 100.00, "", "", ErrPSPUnavailable},
+		{"valor recusado", "", "", 100.05, PSPStatusDeclined, "05", nil},
+		{"valor sem saldo", "", "", 100.51, PSPStatusDeclined, "51", nil},
+		{"tempo esgotado", "", "", 100.91, "", "", ErrPSPTimeout},
+		{"emissor indisponível", "", "", 100.92, "", "", ErrPSPUnavailable},
+		{"pendente", "", "", 100.93, PSPStatusPending, "", nil},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := client.Authorize(context.Background(), &PSPAuthorizationRequest{
+				TransactionID: fmt.Sprintf("tx-magic-%d", i),
+				MerchantID:    "merchant-1",
+				PaymentMethod: PaymentMethodCard,
+				Amount:        tt.amount,
+				Currency:      "USD",
+				CardNumber:    tt.cardNumber,
+				ThreeDSStatus: tt.threeDS,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, response.Status)
+			assert.Equal(t, tt.wantCode, response.ResponseCode)
+			assert.NotEmpty(t, response.Reference)
+		})
+	}
+}
+
+func TestPSPSimulatorIsDeterministic(t *testing.T) {
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{})
+	client := newTestPSPClient(server.URL, "", 0)
+	request := &PSPAuthorizationRequest{TransactionID: "tx-repeat", PaymentMethod: PaymentMethodBankTransfer, Amount: 250, Currency: "USD"}
+
+	first, err := client.Authorize(context.Background(), request)
+	require.NoError(t, err)
+	second, err := client.Authorize(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	resp, err := http.Get(server.URL + "/v1/authorizations/" + first.Reference)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/v1/authorizations/SIM-DESCONHECIDA")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPSPSimulatorRequiresAPIKey(t *testing.T) {
+	_, server := newTestPSPSimulator(t, PSPSimulatorConfig{APIKey: "chave-correta"})
+	request := &PSPAuthorizationRequest{TransactionID: "tx-auth", Amount: 10, Currency: "USD"}
+
+	_, err := newTestPSPClient(server.URL, "chave-errada", 0).Authorize(context.Background(), request)
+	assert.ErrorIs(t, err, ErrPSPRequestRejected)
+
+	_, err = newTestPSPClient(server.URL, "chave-correta", 0).Authorize(context.Background(), request)
+	assert.NoError(t, err)
+}
+
+func TestPSPSimulatorSignsWebhooks(t *testing.T) {
+	var mutex sync.Mutex
+	var received [][]byte
+	var signatures []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		received = append(received, body)
+		signatures = append(signatures, r.Header.Get(PSPSignatureHeader))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	simulator, server := newTestPSPSimulator(t, PSPSimulatorConfig{WebhookURL: receiver.URL})
+	client := newTestPSPClient(server.URL, "", 0)
+
+	_, err := client.Authorize(context.Background(), &PSPAuthorizationRequest{TransactionID: "tx-webhook", Amount: 40.94, Currency: "USD"})
+	require.NoError(t, err)
+	simulator.Wait()
+
+	events := simulator.Webhooks()
+	require.Len(t, events, 1)
+	assert.Equal(t, PSPEventAuthorizationDeclined, events[0].EventType)
+	assert.Equal(t, "05", events[0].ResponseCode)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, received, 1)
+	assert.NoError(t, verifyPSPSignature(testPSPWebhookSecret, signatures[0], received[0], time.Now(), pspSignatureTolerance))
+	assert.Error(t, verifyPSPSignature("outro-segredo", signatures[0], received[0], time.Now(), pspSignatureTolerance))
+}
@@ -0,0 +1,51 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// PSPAuthorizationStore define a persistência das autorizações do PSP, usada na conciliação com
+// os webhooks das autorizações pendentes
+type PSPAuthorizationStore interface {
+	// SavePSPAuthorization grava a autorização, substituindo o estado anterior
+	SavePSPAuthorization(ctx context.Context, authorization *PSPAuthorization) error
+
+	// GetPSPAuthorization recupera a autorização pela referência do PSP; retorna
+	// ErrPSPAuthorizationNotFound quando não existe
+	GetPSPAuthorization(ctx context.Context, reference string) (*PSPAuthorization, error)
+}
+
+// InMemoryPSPAuthorizationStore armazena as autorizações do PSP em memória
+type InMemoryPSPAuthorizationStore struct {
+	authorizations map[string]*PSPAuthorization
+	mutex          sync.RWMutex
+}
+
+// NewInMemoryPSPAuthorizationStore cria um novo armazenamento em memória
+func NewInMemoryPSPAuthorizationStore() *InMemoryPSPAuthorizationStore {
+	return &InMemoryPSPAuthorizationStore{authorizations: make(map[string]*PSPAuthorization)}
+}
+
+// SavePSPAuthorization grava uma cópia da autorização
+func (s *InMemoryPSPAuthorizationStore) SavePSPAuthorization(ctx context.Context, authorization *PSPAuthorization) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *authorization
+	s.authorizations[authorization.Reference] = &copied
+	return nil
+}
+
+// GetPSPAuthorization retorna uma cópia da autorização
+func (s *InMemoryPSPAuthorizationStore) GetPSPAuthorization(ctx context.Context, reference string) (*PSPAuthorization, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	authorization, ok := s.authorizations[reference]
+	if !ok {
+		return nil, ErrPSPAuthorizationNotFound
+	}
+	copied := *authorization
+	return &copied, nil
+}
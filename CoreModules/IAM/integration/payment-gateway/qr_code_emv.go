@@ -0,0 +1,84 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// emvField codifica um campo EMV no formato ID (2 dígitos), tamanho (2 dígitos) e valor
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// parseEMVFields decodifica uma sequência de campos EMV de primeiro nível
+func parseEMVFields(data string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%w: campo truncado", ErrQRCodeInvalidPayload)
+		}
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || len(data) < 4+length {
+			return nil, fmt.Errorf("%w: tamanho inválido no campo %s", ErrQRCodeInvalidPayload, data[:2])
+		}
+		fields[data[:2]] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return fields, nil
+}
+
+// emvTextReplacer remove a acentuação, fora do conjunto de caracteres aceite nos campos EMV
+var emvTextReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "Á", "A", "À", "A", "Â", "A", "Ã", "A",
+	"é", "e", "ê", "e", "É", "E", "Ê", "E", "í", "i", "Í", "I",
+	"ó", "o", "ô", "o", "õ", "o", "Ó", "O", "Ô", "O", "Õ", "O", "ú", "u", "ü", "u", "Ú", "U",
+	"ç", "c", "Ç", "C",
+)
+
+// emvText normaliza texto livre para ASCII imprimível com o tamanho máximo do campo
+func emvText(s string, max int) string {
+	s = emvTextReplacer.Replace(s)
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f && b.Len() < max {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// withEMVCRC acrescenta o campo 63 com o CRC16-CCITT (polinómio 0x1021, valor inicial 0xFFFF)
+func withEMVCRC(payload string) string {
+	payload += "6304"
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(payload); i++ {
+		crc ^= uint16(payload[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return payload + fmt.Sprintf("%04X", crc)
+}
+
+// renderQRCodeSVG desenha os módulos escuros do QR code num SVG com zona de silêncio
+func renderQRCodeSVG(bitmap [][]bool, size int) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/><path fill="#000000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
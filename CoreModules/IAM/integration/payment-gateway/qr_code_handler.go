@@ -0,0 +1,186 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// QRCodeHandler expõe a API pública de QR codes: imagens para o comerciante, cobranças PIX para
+// o PSP do pagador e confirmações de pagamento dos PSPs, assinadas no cabeçalho X-Signature
+type QRCodeHandler struct {
+	service *QRCodeService
+}
+
+// NewQRCodeHandler cria uma nova instância do QRCodeHandler
+func NewQRCodeHandler(service *QRCodeService) *QRCodeHandler {
+	return &QRCodeHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *QRCodeHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/qr/codes/{id:[0-9a-z]+}.{format}", h.RenderQRCode).Methods(http.MethodGet)
+	router.HandleFunc("/qr/codes/{id:[0-9a-z]+}", h.GetQRCode).Methods(http.MethodGet)
+	router.HandleFunc("/qr/pix/jwks", h.PIXJWKS).Methods(http.MethodGet)
+	router.HandleFunc("/qr/pix/{txid}", h.PIXCharge).Methods(http.MethodGet)
+	router.HandleFunc("/qr/webhooks/pix", h.PIXWebhook).Methods(http.MethodPost)
+	router.HandleFunc("/qr/webhooks/emvco", h.EMVCoWebhook).Methods(http.MethodPost)
+}
+
+// GetQRCode retorna o estado e o payload do QR code
+func (h *QRCodeHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	code, err := h.service.GetQRCode(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithQRCodeError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, code)
+}
+
+// RenderQRCode retorna a imagem PNG ou SVG do QR code ativo, com o tamanho no parâmetro size
+func (h *QRCodeHandler) RenderQRCode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+	image, contentType, err := h.service.Render(r.Context(), vars["id"], vars["format"], size)
+	switch {
+	case errors.Is(err, ErrQRCodeNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "QR code não encontrado")
+		return
+	case err != nil:
+		respondWithError(w, http.StatusGone, "qr_code_unavailable", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(image)
+}
+
+// PIXJWKS retorna a chave pública usada na verificação das cobranças PIX
+func (h *QRCodeHandler) PIXJWKS(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.JWKS())
+}
+
+// PIXCharge retorna a cobrança assinada do BR Code dinâmico
+func (h *QRCodeHandler) PIXCharge(w http.ResponseWriter, r *http.Request) {
+	charge, err := h.service.PIXCharge(r.Context(), mux.Vars(r)["txid"])
+	if err != nil {
+		h.respondWithQRCodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jose")
+	w.Header().Set("Cache-Control", "no-store")
+	io.WriteString(w, charge)
+}
+
+// PIXWebhook recebe as notificações de pagamento no formato da API Pix do BACEN
+func (h *QRCodeHandler) PIXWebhook(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readSignedBody(w, r)
+	if !ok {
+		return
+	}
+
+	var notification struct {
+		Pix []struct {
+			EndToEndID string    `json:"endToEndId"`
+			TxID       string    `json:"txid"`
+			Valor      string    `json:"valor"`
+			Horario    time.Time `json:"horario"`
+		} `json:"pix"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo da notificação inválido")
+		return
+	}
+
+	confirmations := make([]QRCodeConfirmation, 0, len(notification.Pix))
+	for _, pix := range notification.Pix {
+		amount, err := strconv.ParseFloat(pix.Valor, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid_request", "Valor inválido na notificação")
+			return
+		}
+		confirmations = append(confirmations, QRCodeConfirmation{
+			QRCodeID:   pix.TxID,
+			EndToEndID: pix.EndToEndID,
+			Amount:     amount,
+			Currency:   "BRL",
+			PaidAt:     pix.Horario,
+		})
+	}
+
+	h.confirm(w, r, confirmations)
+}
+
+// EMVCoWebhook recebe uma QRCodeConfirmation dos PSPs dos esquemas EMVCo
+func (h *QRCodeHandler) EMVCoWebhook(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readSignedBody(w, r)
+	if !ok {
+		return
+	}
+
+	var confirmation QRCodeConfirmation
+	if err := json.Unmarshal(body, &confirmation); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo da confirmação inválido")
+		return
+	}
+
+	h.confirm(w, r, []QRCodeConfirmation{confirmation})
+}
+
+// readSignedBody lê o corpo da notificação e valida a assinatura do PSP
+func (h *QRCodeHandler) readSignedBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Corpo da notificação inválido")
+		return nil, false
+	}
+	if err := h.service.VerifyConfirmationSignature(r.Header.Get(QRCodeSignatureHeader), body); err != nil {
+		h.service.logger.WarnWithContext(r.Context(), "Confirmação de QR code com assinatura inválida",
+			"path", r.URL.Path,
+			"error", err.Error())
+		respondWithError(w, http.StatusUnauthorized, "invalid_signature", "Assinatura da notificação inválida")
+		return nil, false
+	}
+	return body, true
+}
+
+// confirm concilia as confirmações e responde com os QR codes atualizados
+func (h *QRCodeHandler) confirm(w http.ResponseWriter, r *http.Request, confirmations []QRCodeConfirmation) {
+	results := make([]*QRCode, 0, len(confirmations))
+	for _, confirmation := range confirmations {
+		code, _, err := h.service.Confirm(r.Context(), confirmation)
+		if err != nil {
+			h.respondWithQRCodeError(w, err)
+			return
+		}
+		results = append(results, code)
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+// respondWithQRCodeError traduz os erros do fluxo de QR codes para o estado HTTP correspondente
+func (h *QRCodeHandler) respondWithQRCodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrQRCodeNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "QR code não encontrado")
+	case errors.Is(err, ErrQRCodeExpired):
+		respondWithError(w, http.StatusGone, "qr_code_expired", "QR code expirado")
+	case errors.Is(err, ErrQRCodeAlreadyPaid):
+		respondWithError(w, http.StatusConflict, "qr_code_already_paid", "QR code já pago")
+	case errors.Is(err, ErrQRCodeAmountMismatch), errors.Is(err, ErrQRCodeInvalidPayload),
+		errors.Is(err, ErrQRCodeInvalidSignature):
+		respondWithError(w, http.StatusUnprocessableEntity, "invalid_confirmation", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao processar QR code")
+	}
+}
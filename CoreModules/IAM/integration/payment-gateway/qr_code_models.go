@@ -0,0 +1,117 @@
+package paymentgateway
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"time"
+)
+
+// Formatos de QR code de pagamento
+const (
+	QRCodeFormatPIX   = "pix_dynamic" // BR Code dinâmico do PIX (Manual do BR Code, BACEN)
+	QRCodeFormatEMVCo = "emvco"       // QR apresentado pelo comerciante (EMVCo Merchant-Presented Mode)
+)
+
+// Estados de um QR code de pagamento
+const (
+	QRCodeStatusActive  = "active"
+	QRCodeStatusPaid    = "paid"
+	QRCodeStatusExpired = "expired"
+)
+
+// Valores padrão da geração de QR codes
+const (
+	DefaultQRCodeTTL            = 15 * time.Minute
+	DefaultQRCodeExpiryInterval = 30 * time.Second
+	DefaultQRCodeImageSize      = 256
+
+	qrCodeMaxTTL             = 24 * time.Hour
+	qrCodeMaxImageSize       = 1024
+	qrCodeSignatureTolerance = 5 * time.Minute // Desvio máximo do carimbo temporal das confirmações
+	pixGUI                   = "br.gov.bcb.pix"
+	qrCodeGUI                = "com.innovabiz.qr" // Modelo não reservado (ID 80) com a assinatura do gateway
+)
+
+// QRCodeSignatureHeader é o cabeçalho com a assinatura das confirmações enviadas pelos PSPs
+const QRCodeSignatureHeader = "X-Signature"
+
+// Erros do fluxo de QR codes
+var (
+	ErrQRCodeNotFound         = errors.New("QR code não encontrado")
+	ErrQRCodeExpired          = errors.New("QR code expirado")
+	ErrQRCodeAlreadyPaid      = errors.New("QR code já pago")
+	ErrQRCodeAmountMismatch   = errors.New("valor pago difere do valor vinculado ao QR code")
+	ErrQRCodeMerchantUnknown  = errors.New("comerciante sem perfil de QR code")
+	ErrQRCodeInvalidPayload   = errors.New("payload de QR code inválido")
+	ErrQRCodeInvalidSignature = errors.New("assinatura inválida")
+)
+
+// emvCurrencyCodes mapeia as moedas para o código numérico ISO 4217 do campo 53
+var emvCurrencyCodes = map[string]string{
+	"AOA": "973",
+	"BRL": "986",
+	"EUR": "978",
+	"MZN": "943",
+	"USD": "840",
+}
+
+// QRCodeMerchant contém os dados do comerciante impressos no QR code
+type QRCodeMerchant struct {
+	Name       string `json:"name"`        // Nome apresentado ao pagador (máx. 25 caracteres)
+	City       string `json:"city"`        // Cidade do comerciante (máx. 15 caracteres)
+	Country    string `json:"country"`     // País ISO 3166-1 alfa-2
+	MCC        string `json:"mcc"`         // Merchant Category Code
+	AccountGUI string `json:"account_gui"` // GUI do esquema EMVCo (ex.: "ao.emis.mcx"); ignorado no PIX
+	AccountID  string `json:"account_id"`  // Conta do comerciante no esquema ou chave PIX
+}
+
+// QRCode representa um QR code de pagamento com valor vinculado
+type QRCode struct {
+	ID            string     `json:"id" db:"id"` // Também usado como txid da cobrança PIX
+	TenantID      string     `json:"tenant_id" db:"tenant_id"`
+	TransactionID string     `json:"transaction_id" db:"transaction_id"`
+	MerchantID    string     `json:"merchant_id" db:"merchant_id"`
+	UserID        string     `json:"-" db:"user_id"`
+	RegionCode    string     `json:"region_code" db:"region_code"`
+	Format        string     `json:"format" db:"format"`
+	Payload       string     `json:"payload" db:"payload"` // Conteúdo do QR ("copia e cola")
+	Amount        float64    `json:"amount" db:"amount"`
+	Currency      string     `json:"currency" db:"currency"`
+	Description   string     `json:"description,omitempty" db:"description"`
+	Status        string     `json:"status" db:"status"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty" db:"paid_at"`
+	EndToEndID    string     `json:"end_to_end_id,omitempty" db:"end_to_end_id"` // Identificador da liquidação no PSP
+}
+
+// QRCodeConfirmation é a confirmação de pagamento enviada pelo PSP
+type QRCodeConfirmation struct {
+	QRCodeID   string    `json:"qr_code_id"`
+	EndToEndID string    `json:"end_to_end_id"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency,omitempty"`
+	PaidAt     time.Time `json:"paid_at"`
+	Payload    string    `json:"payload,omitempty"` // Payload lido pelo pagador, verificado quando presente
+}
+
+// QRCodeConfig contém as configurações dos pagamentos por QR code
+type QRCodeConfig struct {
+	// Perfil de QR code por comerciante
+	Merchants map[string]QRCodeMerchant `json:"merchants"`
+
+	// Endereço público das cobranças PIX, impresso no campo 26.25 do BR Code
+	PIXLocationURL string `json:"pix_location_url"`
+
+	// Chave ES256 das cobranças PIX; sem chave é gerada uma chave efémera
+	SigningKey *ecdsa.PrivateKey `json:"-"`
+
+	// Segredo HMAC que vincula valor e validade nos QR EMVCo; sem segredo é gerado um aleatório
+	SigningSecret string `json:"-"`
+
+	// Segredo partilhado com os PSPs para assinar as confirmações
+	ConfirmationSecret string `json:"-"`
+
+	TTL            time.Duration `json:"ttl"`
+	ExpiryInterval time.Duration `json:"expiry_interval"`
+}
@@ -0,0 +1,73 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresQRCodeStore implementa QRCodeStore para PostgreSQL
+type PostgresQRCodeStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresQRCodeStore cria uma nova instância de PostgresQRCodeStore
+func NewPostgresQRCodeStore(db *sqlx.DB) *PostgresQRCodeStore {
+	return &PostgresQRCodeStore{db: db}
+}
+
+// qrCodeColumns são as colunas lidas nas consultas de QR codes
+const qrCodeColumns = `id, tenant_id, transaction_id, merchant_id, user_id, region_code, format, payload,
+	amount, currency, description, status, created_at, expires_at, paid_at, end_to_end_id`
+
+// SaveQRCode grava o QR code, substituindo o estado anterior
+func (r *PostgresQRCodeStore) SaveQRCode(ctx context.Context, code *QRCode) error {
+	query := `
+		INSERT INTO payment_gateway.qr_codes (
+			id, tenant_id, transaction_id, merchant_id, user_id, region_code, format, payload,
+			amount, currency, description, status, created_at, expires_at, paid_at, end_to_end_id
+		) VALUES (
+			:id, :tenant_id, :transaction_id, :merchant_id, :user_id, :region_code, :format, :payload,
+			:amount, :currency, :description, :status, :created_at, :expires_at, :paid_at, :end_to_end_id
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			paid_at = EXCLUDED.paid_at,
+			end_to_end_id = EXCLUDED.end_to_end_id
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, code); err != nil {
+		return fmt.Errorf("falha ao gravar QR code: %w", err)
+	}
+
+	return nil
+}
+
+// GetQRCode recupera o QR code pelo identificador
+func (r *PostgresQRCodeStore) GetQRCode(ctx context.Context, id string) (*QRCode, error) {
+	var code QRCode
+	query := `SELECT ` + qrCodeColumns + ` FROM payment_gateway.qr_codes WHERE id = $1`
+	if err := r.db.GetContext(ctx, &code, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrQRCodeNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar QR code: %w", err)
+	}
+	return &code, nil
+}
+
+// ListExpiredQRCodes lista os QR codes ativos cuja validade terminou
+func (r *PostgresQRCodeStore) ListExpiredQRCodes(ctx context.Context, now time.Time) ([]*QRCode, error) {
+	var codes []*QRCode
+	query := `SELECT ` + qrCodeColumns + ` FROM payment_gateway.qr_codes
+		WHERE status = 'active' AND expires_at <= $1
+		ORDER BY expires_at`
+	if err := r.db.SelectContext(ctx, &codes, query, now); err != nil {
+		return nil, fmt.Errorf("falha ao listar QR codes expirados: %w", err)
+	}
+	return codes, nil
+}
@@ -0,0 +1,617 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// QRCodeService gera QR codes de pagamento com valor vinculado e concilia as confirmações dos PSPs
+// O pagamento fica pendente até à confirmação; os QR codes fora da validade são expirados em segundo plano
+type QRCodeService struct {
+	config         QRCodeConfig
+	store          QRCodeStore
+	webhooks       *WebhookDeliveryService
+	pixLocationURL string
+	signingKey     *ecdsa.PrivateKey // Assina as cobranças PIX (JWS ES256)
+	signingSecret  []byte            // Vincula valor e validade nos QR EMVCo
+
+	// Serializa as transições de estado (confirmação e expiração) de cada QR code
+	mutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewQRCodeService cria o serviço de QR codes. Sem chave de assinatura configurada é gerada uma
+// chave efémera, válida apenas durante o processo
+func NewQRCodeService(config QRCodeConfig, store QRCodeStore) (*QRCodeService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-qr-codes",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.TTL <= 0 {
+		config.TTL = DefaultQRCodeTTL
+	}
+	if config.ExpiryInterval <= 0 {
+		config.ExpiryInterval = DefaultQRCodeExpiryInterval
+	}
+
+	logger := obsAdapter.Logger()
+	signingKey := config.SigningKey
+	if signingKey == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao gerar chave de assinatura de QR codes: %w", err)
+		}
+		logger.Warn("Chave de assinatura de QR codes não configurada, usando chave efémera")
+		signingKey = key
+	}
+
+	signingSecret := []byte(config.SigningSecret)
+	if len(signingSecret) == 0 {
+		signingSecret = make([]byte, 32)
+		if _, err := rand.Read(signingSecret); err != nil {
+			return nil, fmt.Errorf("falha ao gerar segredo de QR codes: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &QRCodeService{
+		config:          config,
+		store:           store,
+		pixLocationURL:  strings.TrimSuffix(strings.TrimPrefix(config.PIXLocationURL, "https://"), "/"),
+		signingKey:      signingKey,
+		signingSecret:   signingSecret,
+		logger:          logger,
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes dos QR codes pagos e expirados
+func (s *QRCodeService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.webhooks = webhooks
+}
+
+// Start inicia a expiração periódica dos QR codes fora da validade
+func (s *QRCodeService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.ExpiryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ExpireDue(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Expiração de QR codes iniciada", "interval", s.config.ExpiryInterval.String())
+}
+
+// Stop interrompe a expiração periódica
+func (s *QRCodeService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Generate cria o QR code vinculado ao valor e à moeda do pagamento. O formato é indicado em
+// PaymentDetails["qr_format"] (padrão: PIX no Brasil, EMVCo nos restantes mercados) e a
+// validade em PaymentDetails["qr_ttl_seconds"]
+func (s *QRCodeService) Generate(ctx context.Context, req *PaymentRequest) (*QRCode, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "QRCodeService.Generate")
+	defer span.End()
+
+	merchant, exists := s.config.Merchants[req.MerchantID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrQRCodeMerchantUnknown, req.MerchantID)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: valor deve ser positivo", ErrQRCodeInvalidPayload)
+	}
+
+	format, _ := req.PaymentDetails["qr_format"].(string)
+	if format == "" {
+		format = QRCodeFormatEMVCo
+		if req.RegionCode == RegionBrazil {
+			format = QRCodeFormatPIX
+		}
+	}
+
+	ttl := s.config.TTL
+	if seconds, ok := req.PaymentDetails["qr_ttl_seconds"].(float64); ok && seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl > qrCodeMaxTTL {
+		ttl = qrCodeMaxTTL
+	}
+
+	now := s.now()
+	code := &QRCode{
+		ID:            newQRCodeID(),
+		TenantID:      req.TenantID,
+		TransactionID: req.TransactionID,
+		MerchantID:    req.MerchantID,
+		UserID:        req.UserID,
+		RegionCode:    req.RegionCode,
+		Format:        format,
+		Amount:        roundAmount(req.Amount),
+		Currency:      req.Currency,
+		Description:   req.Description,
+		Status:        QRCodeStatusActive,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(ttl),
+	}
+
+	var err error
+	switch format {
+	case QRCodeFormatPIX:
+		code.Payload, err = s.pixPayload(code, merchant)
+	case QRCodeFormatEMVCo:
+		code.Payload, err = s.emvcoPayload(code, merchant)
+	default:
+		err = fmt.Errorf("%w: formato %s não suportado", ErrQRCodeInvalidPayload, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SaveQRCode(ctx, code); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_qr_codes_total", map[string]string{
+		"format": format,
+		"status": QRCodeStatusActive,
+	})
+
+	// Evento de auditoria do QR code gerado
+	s.logger.InfoWithContext(ctx, "QR code gerado",
+		"tenant_id", req.TenantID,
+		"transaction_id", req.TransactionID,
+		"qr_code_id", code.ID,
+		"format", format,
+		"amount", code.Amount,
+		"currency", code.Currency,
+		"expires_at", code.ExpiresAt.UTC().Format(time.RFC3339))
+	return code, nil
+}
+
+// GetQRCode retorna o QR code, marcando-o como expirado quando a validade terminou
+func (s *QRCodeService) GetQRCode(ctx context.Context, id string) (*QRCode, error) {
+	code, err := s.store.GetQRCode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if code.Status == QRCodeStatusActive && !s.now().Before(code.ExpiresAt) {
+		code.Status = QRCodeStatusExpired
+	}
+	return code, nil
+}
+
+// ExpireDue marca como expirados os QR codes ativos fora da validade e notifica a falha das transações
+func (s *QRCodeService) ExpireDue(ctx context.Context) []*QRCode {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	codes, err := s.store.ListExpiredQRCodes(ctx, s.now())
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao listar QR codes expirados", "error", err.Error())
+		return nil
+	}
+
+	expired := make([]*QRCode, 0, len(codes))
+	for _, code := range codes {
+		code.Status = QRCodeStatusExpired
+		if err := s.store.SaveQRCode(ctx, code); err != nil {
+			s.logger.ErrorWithContext(ctx, "Falha ao expirar QR code",
+				"qr_code_id", code.ID,
+				"error", err.Error())
+			continue
+		}
+		s.notify(ctx, code, WebhookEventTransactionFailed, ErrQRCodeExpired.Error())
+		expired = append(expired, code)
+	}
+	return expired
+}
+
+// Confirm concilia a confirmação do PSP com o QR code. Confirmações repetidas com o mesmo
+// end_to_end_id retornam o QR code sem alteração; o boolean indica se o pagamento foi registado agora
+func (s *QRCodeService) Confirm(ctx context.Context, confirmation QRCodeConfirmation) (*QRCode, bool, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "QRCodeService.Confirm")
+	defer span.End()
+
+	if confirmation.Payload != "" {
+		id, err := s.VerifyEMVCoPayload(confirmation.Payload)
+		if err != nil {
+			return nil, false, err
+		}
+		// Um payload válido de outro QR code não confirma este
+		if id != confirmation.QRCodeID {
+			return nil, false, fmt.Errorf("%w: payload do QR code %s confirmado como %s", ErrQRCodeInvalidPayload,
+				id, confirmation.QRCodeID)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	code, err := s.store.GetQRCode(ctx, confirmation.QRCodeID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	code, paid, err := s.reconcile(code, confirmation)
+	if err != nil {
+		span.RecordError(err)
+		if code.Status == QRCodeStatusExpired {
+			if saveErr := s.store.SaveQRCode(ctx, code); saveErr != nil {
+				return nil, false, saveErr
+			}
+		}
+		// Evento de segurança: confirmação recusada, possível QR code adulterado ou pagamento duplicado
+		s.logger.WarnWithContext(ctx, "Confirmação de QR code recusada",
+			"tenant_id", code.TenantID,
+			"qr_code_id", code.ID,
+			"transaction_id", code.TransactionID,
+			"end_to_end_id", confirmation.EndToEndID,
+			"error", err.Error())
+		return code, false, err
+	}
+	if !paid {
+		return code, false, nil
+	}
+
+	if err := s.store.SaveQRCode(ctx, code); err != nil {
+		span.RecordError(err)
+		return nil, false, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_qr_codes_total", map[string]string{
+		"format": code.Format,
+		"status": QRCodeStatusPaid,
+	})
+
+	// Evento de auditoria do pagamento concluído
+	s.logger.InfoWithContext(ctx, "QR code pago",
+		"tenant_id", code.TenantID,
+		"qr_code_id", code.ID,
+		"transaction_id", code.TransactionID,
+		"end_to_end_id", code.EndToEndID,
+		"amount", code.Amount,
+		"currency", code.Currency)
+	s.notify(ctx, code, WebhookEventTransactionCompleted, "")
+	return code, true, nil
+}
+
+// reconcile aplica a confirmação ao QR code sem o gravar
+func (s *QRCodeService) reconcile(code *QRCode, confirmation QRCodeConfirmation) (*QRCode, bool, error) {
+	switch code.Status {
+	case QRCodeStatusPaid:
+		if code.EndToEndID == confirmation.EndToEndID {
+			return code, false, nil
+		}
+		return code, false, ErrQRCodeAlreadyPaid
+	case QRCodeStatusExpired:
+		return code, false, ErrQRCodeExpired
+	}
+
+	paidAt := confirmation.PaidAt
+	if paidAt.IsZero() {
+		paidAt = s.now()
+	}
+	if !paidAt.Before(code.ExpiresAt) {
+		code.Status = QRCodeStatusExpired
+		return code, false, ErrQRCodeExpired
+	}
+
+	// O valor liquidado tem de coincidir ao cêntimo com o valor vinculado ao QR code
+	if math.Round(confirmation.Amount*100) != math.Round(code.Amount*100) ||
+		(confirmation.Currency != "" && confirmation.Currency != code.Currency) {
+		return code, false, fmt.Errorf("%w: esperado %.2f %s, recebido %.2f %s", ErrQRCodeAmountMismatch,
+			code.Amount, code.Currency, confirmation.Amount, confirmation.Currency)
+	}
+
+	code.Status = QRCodeStatusPaid
+	code.PaidAt = &paidAt
+	code.EndToEndID = confirmation.EndToEndID
+	return code, true, nil
+}
+
+// notify agenda a notificação ao comerciante do desfecho do pagamento por QR code
+func (s *QRCodeService) notify(ctx context.Context, code *QRCode, eventType, reason string) {
+	if s.webhooks == nil {
+		return
+	}
+
+	status := TransactionStatusApproved
+	if eventType == WebhookEventTransactionFailed {
+		status = TransactionStatusDenied
+	}
+	if _, err := s.webhooks.Enqueue(ctx, WebhookEvent{
+		EventType:     eventType,
+		TenantID:      code.TenantID,
+		MerchantID:    code.MerchantID,
+		TransactionID: code.TransactionID,
+		Status:        status,
+		PaymentMethod: PaymentMethodQRCode,
+		Amount:        code.Amount,
+		Currency:      code.Currency,
+		ProcessorRef:  code.EndToEndID,
+		Reason:        reason,
+		Market:        code.RegionCode,
+	}); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao agendar notificação do QR code",
+			"qr_code_id", code.ID,
+			"transaction_id", code.TransactionID,
+			"error", err.Error())
+	}
+}
+
+// VerifyConfirmationSignature valida o cabeçalho "t=<unix>,v1=<hmac>" de uma confirmação do PSP,
+// calculado como em SignPSPPayload com o segredo partilhado
+func (s *QRCodeService) VerifyConfirmationSignature(header string, body []byte) error {
+	if s.config.ConfirmationSecret == "" {
+		return fmt.Errorf("%w: segredo de confirmação não configurado", ErrQRCodeInvalidSignature)
+	}
+
+	if err := verifyPSPSignature(s.config.ConfirmationSecret, header, body, s.now(), qrCodeSignatureTolerance); err != nil {
+		return fmt.Errorf("%w: %v", ErrQRCodeInvalidSignature, err)
+	}
+	return nil
+}
+
+// pixPayload monta o BR Code dinâmico: o valor e a validade ficam na cobrança assinada
+// servida no endereço do campo 26.25 (ver PIXCharge)
+func (s *QRCodeService) pixPayload(code *QRCode, merchant QRCodeMerchant) (string, error) {
+	if code.Currency != "BRL" {
+		return "", fmt.Errorf("%w: PIX exige BRL, recebido %s", ErrQRCodeInvalidPayload, code.Currency)
+	}
+	if s.pixLocationURL == "" {
+		return "", fmt.Errorf("%w: URL de localização PIX não configurada", ErrQRCodeInvalidPayload)
+	}
+
+	var b strings.Builder
+	b.WriteString(emvField("00", "01"))
+	b.WriteString(emvField("01", "12")) // Uso único
+	b.WriteString(emvField("26", emvField("00", pixGUI)+emvField("25", s.pixLocationURL+"/"+code.ID)))
+	b.WriteString(emvField("52", merchant.MCC))
+	b.WriteString(emvField("53", emvCurrencyCodes["BRL"]))
+	b.WriteString(emvField("58", "BR"))
+	b.WriteString(emvField("59", emvText(merchant.Name, 25)))
+	b.WriteString(emvField("60", emvText(merchant.City, 15)))
+	b.WriteString(emvField("62", emvField("05", "***")))
+	return withEMVCRC(b.String()), nil
+}
+
+// emvcoPayload monta o QR EMVCo com o valor no campo 54 e, no modelo 80, o identificador,
+// a validade e a assinatura que vinculam o valor ao QR code
+func (s *QRCodeService) emvcoPayload(code *QRCode, merchant QRCodeMerchant) (string, error) {
+	currency, exists := emvCurrencyCodes[code.Currency]
+	if !exists {
+		return "", fmt.Errorf("%w: moeda %s sem código ISO 4217", ErrQRCodeInvalidPayload, code.Currency)
+	}
+
+	amount := strconv.FormatFloat(code.Amount, 'f', 2, 64)
+	expiresAt := strconv.FormatInt(code.ExpiresAt.Unix(), 10)
+
+	var b strings.Builder
+	b.WriteString(emvField("00", "01"))
+	b.WriteString(emvField("01", "12"))
+	b.WriteString(emvField("26", emvField("00", merchant.AccountGUI)+emvField("01", merchant.AccountID)))
+	b.WriteString(emvField("52", merchant.MCC))
+	b.WriteString(emvField("53", currency))
+	b.WriteString(emvField("54", amount))
+	b.WriteString(emvField("58", merchant.Country))
+	b.WriteString(emvField("59", emvText(merchant.Name, 25)))
+	b.WriteString(emvField("60", emvText(merchant.City, 15)))
+	b.WriteString(emvField("80", emvField("00", qrCodeGUI)+emvField("01", code.ID)+emvField("02", expiresAt)+
+		emvField("03", s.emvcoSignature(code.ID, amount, currency, expiresAt))))
+	return withEMVCRC(b.String()), nil
+}
+
+// emvcoSignature calcula o HMAC truncado que vincula valor, moeda e validade ao QR code
+func (s *QRCodeService) emvcoSignature(id, amount, currency, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte(strings.Join([]string{id, amount, currency, expiresAt}, "|")))
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// VerifyEMVCoPayload valida o CRC, a assinatura e a validade de um payload EMVCo lido pelo
+// pagador, detetando QR codes adulterados (ex.: valor alterado), e retorna o identificador
+// do QR code vinculado pela assinatura
+func (s *QRCodeService) VerifyEMVCoPayload(payload string) (string, error) {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != "6304" ||
+		withEMVCRC(payload[:len(payload)-8]) != payload {
+		return "", fmt.Errorf("%w: CRC inválido", ErrQRCodeInvalidPayload)
+	}
+
+	fields, err := parseEMVFields(payload[:len(payload)-8])
+	if err != nil {
+		return "", err
+	}
+	template, err := parseEMVFields(fields["80"])
+	if err != nil {
+		return "", err
+	}
+	if template["00"] != qrCodeGUI {
+		return "", fmt.Errorf("%w: QR code não emitido pelo gateway", ErrQRCodeInvalidPayload)
+	}
+
+	expected := s.emvcoSignature(template["01"], fields["54"], fields["53"], template["02"])
+	if !hmac.Equal([]byte(expected), []byte(template["03"])) {
+		return "", fmt.Errorf("%w: QR code adulterado", ErrQRCodeInvalidSignature)
+	}
+
+	expiresAt, err := strconv.ParseInt(template["02"], 10, 64)
+	if err != nil || !s.now().Before(time.Unix(expiresAt, 0)) {
+		return "", ErrQRCodeExpired
+	}
+	return template["01"], nil
+}
+
+// PIXCharge retorna a cobrança do BR Code dinâmico assinada em JWS (ES256), no formato da
+// API Pix do BACEN, para o PSP do pagador que lê o endereço do campo 26.25
+func (s *QRCodeService) PIXCharge(ctx context.Context, id string) (string, error) {
+	code, err := s.GetQRCode(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if code.Format != QRCodeFormatPIX {
+		return "", ErrQRCodeNotFound
+	}
+	if code.Status == QRCodeStatusExpired {
+		return "", ErrQRCodeExpired
+	}
+
+	merchant := s.config.Merchants[code.MerchantID]
+	status := "ATIVA"
+	if code.Status == QRCodeStatusPaid {
+		status = "CONCLUIDA"
+	}
+	charge := map[string]interface{}{
+		"txid":    code.ID,
+		"revisao": 0,
+		"status":  status,
+		"calendario": map[string]interface{}{
+			"criacao":      code.CreatedAt.UTC().Format(time.RFC3339),
+			"apresentacao": s.now().UTC().Format(time.RFC3339),
+			"expiracao":    int(code.ExpiresAt.Sub(code.CreatedAt).Seconds()),
+		},
+		"valor": map[string]string{"original": strconv.FormatFloat(code.Amount, 'f', 2, 64)},
+		"chave": merchant.AccountID,
+	}
+	if code.Description != "" {
+		charge["solicitacaoPagador"] = code.Description
+	}
+	return s.signJWS(charge)
+}
+
+// JWKS retorna a chave pública usada na verificação das cobranças PIX
+func (s *QRCodeService) JWKS() map[string]interface{} {
+	public := s.signingKey.PublicKey
+	return map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"crv": "P-256",
+			"use": "sig",
+			"alg": "ES256",
+			"kid": s.keyID(),
+			"x":   base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32))),
+		}},
+	}
+}
+
+// signJWS serializa e assina o conteúdo em JWS compacto com ES256
+func (s *QRCodeService) signJWS(claims interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT", "kid": s.keyID()})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar cobrança PIX: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.signingKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("falha ao assinar cobrança PIX: %w", err)
+	}
+
+	signature := append(r.FillBytes(make([]byte, 32)), sig.FillBytes(make([]byte, 32))...)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// keyID deriva o identificador da chave de assinatura a partir da chave pública
+func (s *QRCodeService) keyID() string {
+	public := s.signingKey.PublicKey
+	digest := sha256.Sum256(append(public.X.FillBytes(make([]byte, 32)), public.Y.FillBytes(make([]byte, 32))...))
+	return hex.EncodeToString(digest[:8])
+}
+
+// Render gera a imagem do QR code em PNG ou SVG com o tamanho indicado em pixels
+func (s *QRCodeService) Render(ctx context.Context, id, imageFormat string, size int) ([]byte, string, error) {
+	code, err := s.GetQRCode(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if code.Status != QRCodeStatusActive {
+		return nil, "", fmt.Errorf("QR code %s no estado %s", id, code.Status)
+	}
+
+	if size <= 0 {
+		size = DefaultQRCodeImageSize
+	}
+	if size > qrCodeMaxImageSize {
+		size = qrCodeMaxImageSize
+	}
+
+	qr, err := qrcode.New(code.Payload, qrcode.Medium)
+	if err != nil {
+		return nil, "", fmt.Errorf("falha ao codificar QR code: %w", err)
+	}
+
+	switch imageFormat {
+	case "png":
+		image, err := qr.PNG(size)
+		if err != nil {
+			return nil, "", fmt.Errorf("falha ao gerar PNG: %w", err)
+		}
+		return image, "image/png", nil
+	case "svg":
+		return renderQRCodeSVG(qr.Bitmap(), size), "image/svg+xml", nil
+	default:
+		return nil, "", fmt.Errorf("formato de imagem %s não suportado", imageFormat)
+	}
+}
+
+// newQRCodeID gera um identificador alfanumérico de 26 caracteres, válido como txid PIX
+func newQRCodeID() string {
+	buf := make([]byte, 13)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%026d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,403 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQRCodeService(t *testing.T) (*QRCodeService, *time.Time) {
+	t.Helper()
+
+	service, err := NewQRCodeService(QRCodeConfig{
+		Merchants: map[string]QRCodeMerchant{
+			"merchant-1": {Name: "Padaria Açúcar Doce Lda", City: "Luanda", Country: "AO", MCC: "5462",
+				AccountGUI: "ao.emis.mcx", AccountID: "AO06004000001234567890123"},
+		},
+		PIXLocationURL:     "https://pix.innovabiz.example/qr/pix/",
+		SigningSecret:      "segredo-qr",
+		ConfirmationSecret: "segredo-psp",
+	}, NewInMemoryQRCodeStore())
+	require.NoError(t, err)
+
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, &clock
+}
+
+func testQRCodeRequest(region, currency string, amount float64) *PaymentRequest {
+	req := testRiskRequest(region, currency, amount)
+	req.PaymentMethod = PaymentMethodQRCode
+	return req
+}
+
+func TestEMVFields(t *testing.T) {
+	payload := emvField("00", "01") + emvField("26", emvField("00", pixGUI)+emvField("25", "pix.example/abc"))
+	assert.Equal(t, "000201", payload[:6])
+
+	fields, err := parseEMVFields(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "01", fields["00"])
+
+	nested, err := parseEMVFields(fields["26"])
+	require.NoError(t, err)
+	assert.Equal(t, pixGUI, nested["00"])
+	assert.Equal(t, "pix.example/abc", nested["25"])
+
+	for _, malformed := range []string{"000", "0005abc", "00xx01"} {
+		_, err := parseEMVFields(malformed)
+		assert.ErrorIs(t, err, ErrQRCodeInvalidPayload, malformed)
+	}
+
+	assert.Equal(t, "Acucar Sao Joao", emvText("Açúcar São João", 25))
+	assert.Equal(t, "Padaria", emvText("Padaria Central", 7))
+
+	// Exemplo do Manual do BR Code: o CRC16-CCITT cobre o payload e o próprio identificador 6304
+	brCode := "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+		"5204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***"
+	assert.Equal(t, brCode+"63041D3D", withEMVCRC(brCode))
+}
+
+func TestQRCodeGenerate(t *testing.T) {
+	service, clock := newTestQRCodeService(t)
+
+	t.Run("EMVCo fora do Brasil com valor e assinatura", func(t *testing.T) {
+		code, err := service.Generate(context.Background(), testQRCodeRequest(RegionAngola, "AOA", 1500.456))
+		require.NoError(t, err)
+		assert.Equal(t, QRCodeFormatEMVCo, code.Format)
+		assert.Equal(t, QRCodeStatusActive, code.Status)
+		assert.Equal(t, 1500.46, code.Amount)
+		assert.Equal(t, clock.Add(DefaultQRCodeTTL), code.ExpiresAt)
+		assert.Len(t, code.ID, 26)
+
+		fields, err := parseEMVFields(code.Payload[:len(code.Payload)-8])
+		require.NoError(t, err)
+		assert.Equal(t, "1500.46", fields["54"])
+		assert.Equal(t, "973", fields["53"])
+		assert.Equal(t, "Padaria Acucar Doce Lda", fields["59"])
+
+		id, err := service.VerifyEMVCoPayload(code.Payload)
+		require.NoError(t, err)
+		assert.Equal(t, code.ID, id)
+	})
+
+	t.Run("PIX dinâmico no Brasil", func(t *testing.T) {
+		code, err := service.Generate(context.Background(), testQRCodeRequest(RegionBrazil, "BRL", 99.9))
+		require.NoError(t, err)
+		assert.Equal(t, QRCodeFormatPIX, code.Format)
+		assert.Contains(t, code.Payload, "pix.innovabiz.example/qr/pix/"+code.ID)
+		assert.NotContains(t, code.Payload, "5405", "o valor do PIX dinâmico fica na cobrança assinada")
+	})
+
+	t.Run("validade pedida limitada ao máximo", func(t *testing.T) {
+		req := testQRCodeRequest(RegionAngola, "AOA", 100)
+		req.PaymentDetails = map[string]interface{}{"qr_ttl_seconds": float64(72 * 3600)}
+		code, err := service.Generate(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, clock.Add(qrCodeMaxTTL), code.ExpiresAt)
+	})
+
+	tests := []struct {
+		name    string
+		request func() *PaymentRequest
+		err     error
+	}{
+		{name: "comerciante sem perfil", request: func() *PaymentRequest {
+			req := testQRCodeRequest(RegionAngola, "AOA", 100)
+			req.MerchantID = "merchant-2"
+			return req
+		}, err: ErrQRCodeMerchantUnknown},
+		{name: "PIX em moeda diferente de BRL", request: func() *PaymentRequest {
+			req := testQRCodeRequest(RegionAngola, "AOA", 100)
+			req.PaymentDetails = map[string]interface{}{"qr_format": QRCodeFormatPIX}
+			return req
+		}, err: ErrQRCodeInvalidPayload},
+		{name: "moeda sem código ISO 4217", request: func() *PaymentRequest {
+			return testQRCodeRequest(RegionAngola, "JPY", 100)
+		}, err: ErrQRCodeInvalidPayload},
+		{name: "valor não positivo", request: func() *PaymentRequest {
+			return testQRCodeRequest(RegionAngola, "AOA", 0)
+		}, err: ErrQRCodeInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Generate(context.Background(), tt.request())
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestQRCodeVerifyEMVCoPayloadDetectsTampering(t *testing.T) {
+	service, clock := newTestQRCodeService(t)
+	code, err := service.Generate(context.Background(), testQRCodeRequest(RegionAngola, "AOA", 1500))
+	require.NoError(t, err)
+
+	// Alterar o valor e recalcular o CRC não reproduz a assinatura do gateway
+	body := code.Payload[:len(code.Payload)-8]
+	tampered := withEMVCRC(strings.Replace(body, emvField("54", "1500.00"), emvField("54", "15.00"), 1))
+	_, err = service.VerifyEMVCoPayload(tampered)
+	assert.ErrorIs(t, err, ErrQRCodeInvalidSignature)
+
+	_, err = service.VerifyEMVCoPayload(body + "6304FFFF")
+	assert.ErrorIs(t, err, ErrQRCodeInvalidPayload)
+
+	*clock = clock.Add(DefaultQRCodeTTL)
+	_, err = service.VerifyEMVCoPayload(code.Payload)
+	assert.ErrorIs(t, err, ErrQRCodeExpired)
+}
+
+func TestQRCodeConfirm(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pagamento conciliado e confirmação repetida idempotente", func(t *testing.T) {
+		service, clock := newTestQRCodeService(t)
+		code, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+		require.NoError(t, err)
+
+		confirmation := QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 1500, Currency: "AOA",
+			PaidAt: clock.Add(time.Minute), Payload: code.Payload}
+		paid, recorded, err := service.Confirm(ctx, confirmation)
+		require.NoError(t, err)
+		assert.True(t, recorded)
+		assert.Equal(t, QRCodeStatusPaid, paid.Status)
+		assert.Equal(t, "E2E-1", paid.EndToEndID)
+
+		_, recorded, err = service.Confirm(ctx, confirmation)
+		require.NoError(t, err)
+		assert.False(t, recorded)
+
+		confirmation.EndToEndID = "E2E-2"
+		_, _, err = service.Confirm(ctx, confirmation)
+		assert.ErrorIs(t, err, ErrQRCodeAlreadyPaid)
+	})
+
+	t.Run("valor diferente do vinculado", func(t *testing.T) {
+		service, _ := newTestQRCodeService(t)
+		code, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+		require.NoError(t, err)
+
+		_, recorded, err := service.Confirm(ctx, QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 1499.99})
+		assert.ErrorIs(t, err, ErrQRCodeAmountMismatch)
+		assert.False(t, recorded)
+
+		stored, err := service.GetQRCode(ctx, code.ID)
+		require.NoError(t, err)
+		assert.Equal(t, QRCodeStatusActive, stored.Status)
+	})
+
+	t.Run("payload de outro QR code", func(t *testing.T) {
+		service, _ := newTestQRCodeService(t)
+		first, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+		require.NoError(t, err)
+		second, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+		require.NoError(t, err)
+
+		_, _, err = service.Confirm(ctx, QRCodeConfirmation{QRCodeID: second.ID, EndToEndID: "E2E-1", Amount: 1500,
+			Payload: first.Payload})
+		assert.ErrorIs(t, err, ErrQRCodeInvalidPayload)
+	})
+
+	t.Run("pagamento após a validade", func(t *testing.T) {
+		service, clock := newTestQRCodeService(t)
+		code, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+		require.NoError(t, err)
+
+		_, _, err = service.Confirm(ctx, QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 1500,
+			PaidAt: clock.Add(DefaultQRCodeTTL)})
+		assert.ErrorIs(t, err, ErrQRCodeExpired)
+
+		stored, err := service.store.GetQRCode(ctx, code.ID)
+		require.NoError(t, err)
+		assert.Equal(t, QRCodeStatusExpired, stored.Status)
+	})
+
+	t.Run("QR code inexistente", func(t *testing.T) {
+		service, _ := newTestQRCodeService(t)
+		_, _, err := service.Confirm(ctx, QRCodeConfirmation{QRCodeID: "inexistente"})
+		assert.ErrorIs(t, err, ErrQRCodeNotFound)
+	})
+}
+
+func TestQRCodeExpireDueNotifiesMerchant(t *testing.T) {
+	ctx := context.Background()
+	service, clock := newTestQRCodeService(t)
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	service.SetWebhookDeliveryService(webhooks)
+
+	expiring, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 1500))
+	require.NoError(t, err)
+	req := testQRCodeRequest(RegionAngola, "AOA", 1500)
+	req.PaymentDetails = map[string]interface{}{"qr_ttl_seconds": float64(3600)}
+	active, err := service.Generate(ctx, req)
+	require.NoError(t, err)
+
+	*clock = clock.Add(DefaultQRCodeTTL)
+	expired := service.ExpireDue(ctx)
+	require.Len(t, expired, 1)
+	assert.Equal(t, expiring.ID, expired[0].ID)
+
+	stored, err := service.GetQRCode(ctx, active.ID)
+	require.NoError(t, err)
+	assert.Equal(t, QRCodeStatusActive, stored.Status)
+
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, WebhookEventTransactionFailed, list[0].Event.EventType)
+	assert.Equal(t, PaymentMethodQRCode, list[0].Event.PaymentMethod)
+
+	assert.Empty(t, service.ExpireDue(ctx), "QR codes já expirados não voltam a ser notificados")
+}
+
+func TestQRCodePIXChargeSignedWithJWKS(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestQRCodeService(t)
+	code, err := service.Generate(ctx, testQRCodeRequest(RegionBrazil, "BRL", 99.9))
+	require.NoError(t, err)
+
+	charge, err := service.PIXCharge(ctx, code.ID)
+	require.NoError(t, err)
+	parts := strings.Split(charge, ".")
+	require.Len(t, parts, 3)
+
+	var claims map[string]interface{}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &claims))
+	assert.Equal(t, code.ID, claims["txid"])
+	assert.Equal(t, "ATIVA", claims["status"])
+	assert.Equal(t, map[string]interface{}{"original": "99.90"}, claims["valor"])
+
+	// A assinatura confere com a chave publicada no JWKS
+	jwk := service.JWKS()["keys"].([]map[string]string)[0]
+	x, _ := base64.RawURLEncoding.DecodeString(jwk["x"])
+	y, _ := base64.RawURLEncoding.DecodeString(jwk["y"])
+	public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.True(t, ecdsa.Verify(public, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])))
+
+	emvco, err := service.Generate(ctx, testQRCodeRequest(RegionAngola, "AOA", 100))
+	require.NoError(t, err)
+	_, err = service.PIXCharge(ctx, emvco.ID)
+	assert.ErrorIs(t, err, ErrQRCodeNotFound)
+}
+
+func TestQRCodeHandler(t *testing.T) {
+	service, clock := newTestQRCodeService(t)
+	code, err := service.Generate(context.Background(), testQRCodeRequest(RegionAngola, "AOA", 1500))
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	NewQRCodeHandler(service).RegisterRoutes(router)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	signed := func(path string, body []byte, secret string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set(QRCodeSignatureHeader, SignPSPPayload(secret, *clock, body))
+		return req
+	}
+
+	t.Run("imagens PNG e SVG", func(t *testing.T) {
+		rec := serve(httptest.NewRequest(http.MethodGet, "/qr/codes/"+code.ID+".png?size=128", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+		assert.True(t, bytes.HasPrefix(rec.Body.Bytes(), []byte("\x89PNG")))
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/codes/"+code.ID+".svg", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/codes/inexistente.png", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("confirmação com assinatura inválida", func(t *testing.T) {
+		body, _ := json.Marshal(QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 1500})
+		rec := serve(signed("/qr/webhooks/emvco", body, "outro-segredo"))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("confirmação EMVCo com valor divergente", func(t *testing.T) {
+		body, _ := json.Marshal(QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 15})
+		rec := serve(signed("/qr/webhooks/emvco", body, "segredo-psp"))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("confirmação EMVCo assinada", func(t *testing.T) {
+		body, _ := json.Marshal(QRCodeConfirmation{QRCodeID: code.ID, EndToEndID: "E2E-1", Amount: 1500})
+		rec := serve(signed("/qr/webhooks/emvco", body, "segredo-psp"))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/codes/"+code.ID, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var stored QRCode
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&stored))
+		assert.Equal(t, QRCodeStatusPaid, stored.Status)
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/codes/"+code.ID+".png", nil))
+		assert.Equal(t, http.StatusGone, rec.Code)
+	})
+
+	t.Run("notificação PIX no formato do BACEN", func(t *testing.T) {
+		pix, err := service.Generate(context.Background(), testQRCodeRequest(RegionBrazil, "BRL", 99.9))
+		require.NoError(t, err)
+
+		body := []byte(`{"pix":[{"endToEndId":"E2E-PIX","txid":"` + pix.ID + `","valor":"99.90","horario":"` +
+			clock.Add(time.Minute).Format(time.RFC3339) + `"}]}`)
+		rec := serve(signed("/qr/webhooks/pix", body, "segredo-psp"))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/pix/"+pix.ID, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/jose", rec.Header().Get("Content-Type"))
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/qr/pix/jwks", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestProcessPaymentQRCodePending(t *testing.T) {
+	connector, _ := newTestConnector(t)
+	service, _ := newTestQRCodeService(t)
+	connector.SetQRCodeService(service)
+
+	response, err := connector.ProcessPayment(context.Background(), testQRCodeRequest(RegionAngola, "AOA", 1500))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusPending, response.Status)
+	assert.Equal(t, "qr_code_gerado", response.StatusCode)
+	require.Contains(t, response.Metadata, "qr_code_id")
+
+	code, err := service.GetQRCode(context.Background(), response.Metadata["qr_code_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", code.TransactionID)
+	assert.Equal(t, code.Payload, response.Metadata["qr_code_payload"])
+
+	req := testQRCodeRequest(RegionAngola, "AOA", 1500)
+	req.RequestID = "req-2"
+	req.MerchantID = "merchant-2"
+	response, err = connector.ProcessPayment(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusError, response.Status)
+	assert.Equal(t, "qr_code_erro", response.StatusCode)
+}
@@ -0,0 +1,75 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// QRCodeStore define a persistência dos QR codes de pagamento
+type QRCodeStore interface {
+	// SaveQRCode grava o QR code, substituindo o estado anterior
+	SaveQRCode(ctx context.Context, code *QRCode) error
+
+	// GetQRCode recupera o QR code; retorna ErrQRCodeNotFound quando não existe
+	GetQRCode(ctx context.Context, id string) (*QRCode, error)
+
+	// ListExpiredQRCodes lista os QR codes ainda ativos cuja validade terminou até ao instante indicado
+	ListExpiredQRCodes(ctx context.Context, now time.Time) ([]*QRCode, error)
+}
+
+// InMemoryQRCodeStore armazena os QR codes em memória
+type InMemoryQRCodeStore struct {
+	codes map[string]*QRCode
+	mutex sync.RWMutex
+}
+
+// NewInMemoryQRCodeStore cria um novo armazenamento em memória
+func NewInMemoryQRCodeStore() *InMemoryQRCodeStore {
+	return &InMemoryQRCodeStore{codes: make(map[string]*QRCode)}
+}
+
+// SaveQRCode grava uma cópia do QR code
+func (s *InMemoryQRCodeStore) SaveQRCode(ctx context.Context, code *QRCode) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.codes[code.ID] = copyQRCode(code)
+	return nil
+}
+
+// GetQRCode retorna uma cópia do QR code
+func (s *InMemoryQRCodeStore) GetQRCode(ctx context.Context, id string) (*QRCode, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	code, ok := s.codes[id]
+	if !ok {
+		return nil, ErrQRCodeNotFound
+	}
+	return copyQRCode(code), nil
+}
+
+// ListExpiredQRCodes lista cópias dos QR codes ativos fora da validade
+func (s *InMemoryQRCodeStore) ListExpiredQRCodes(ctx context.Context, now time.Time) ([]*QRCode, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var expired []*QRCode
+	for _, code := range s.codes {
+		if code.Status == QRCodeStatusActive && !now.Before(code.ExpiresAt) {
+			expired = append(expired, copyQRCode(code))
+		}
+	}
+	return expired, nil
+}
+
+// copyQRCode copia o QR code e a data de pagamento
+func copyQRCode(code *QRCode) *QRCode {
+	copied := *code
+	if code.PaidAt != nil {
+		paidAt := *code.PaidAt
+		copied.PaidAt = &paidAt
+	}
+	return &copied
+}
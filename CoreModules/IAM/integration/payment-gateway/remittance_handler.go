@@ -0,0 +1,48 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RemittanceHandler expõe às equipas de suporte os corredores e o estado das remessas
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type RemittanceHandler struct {
+	service *RemittanceService
+}
+
+// NewRemittanceHandler cria uma nova instância do RemittanceHandler
+func NewRemittanceHandler(service *RemittanceService) *RemittanceHandler {
+	return &RemittanceHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *RemittanceHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/remittances/corridors", h.ListCorridors).Methods(http.MethodGet)
+	router.HandleFunc("/support/remittances/{transactionId}", h.GetRemittance).Methods(http.MethodGet)
+}
+
+// ListCorridors lista os corredores configurados com comissões e margens cambiais
+func (h *RemittanceHandler) ListCorridors(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.service.Corridors())
+}
+
+// GetRemittance retorna as pernas e o estado de uma remessa do tenant
+func (h *RemittanceHandler) GetRemittance(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get("X-Tenant-ID")
+	transactionID := mux.Vars(r)["transactionId"]
+
+	remittance, err := h.service.GetRemittance(r.Context(), tenantID, transactionID)
+	if err != nil {
+		if errors.Is(err, ErrRemittanceNotFound) {
+			respondWithError(w, http.StatusNotFound, "not_found", "Remessa não encontrada")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao recuperar remessa")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, remittance)
+}
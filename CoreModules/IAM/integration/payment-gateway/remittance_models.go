@@ -0,0 +1,175 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Pernas de uma remessa internacional
+const (
+	RemittanceLegDebit  = "debit"  // Débito ao remetente na moeda de origem
+	RemittanceLegFX     = "fx"     // Conversão cambial para a moeda de destino
+	RemittanceLegCredit = "credit" // Crédito ao beneficiário pelo parceiro de pagamento
+)
+
+// Estados de uma remessa e das suas pernas
+const (
+	RemittanceStatusPending   = "pending"
+	RemittanceStatusCompleted = "completed"
+	RemittanceStatusFailed    = "failed"
+	RemittanceStatusReversed  = "reversed" // Débito estornado após falha no crédito
+)
+
+// Hooks de compliance aplicáveis por corredor
+const (
+	RemittanceHookBNAExchange = "bna_exchange_authorization" // Autorização cambial do BNA (Angola)
+	RemittanceHookOFAC        = "ofac_screening"             // Triagem OFAC do beneficiário (EUA)
+)
+
+// Valores padrão do fluxo de remessas
+const (
+	DefaultRemittancePayoutTimeout = 15 * time.Second
+
+	// Operações cambiais acima deste valor em kwanzas exigem referência de autorização do BNA
+	bnaExchangeAuthorizationThresholdAOA = 5000000
+)
+
+// Erros do fluxo de remessas
+var (
+	ErrRemittanceNotFound        = errors.New("remessa não encontrada")
+	ErrRemittanceRejected        = errors.New("remessa recusada antes do débito")
+	ErrRemittanceDetailsMissing  = errors.New("dados da remessa ausentes no pagamento")
+	ErrRemittanceCorridorUnknown = errors.New("corredor de remessa não configurado")
+	ErrRemittancePayoutFailed    = errors.New("falha no crédito da remessa")
+)
+
+// ofacSanctionedCountries são os destinos sob embargo abrangente da OFAC
+var ofacSanctionedCountries = map[string]bool{"CU": true, "IR": true, "KP": true, "SY": true}
+
+// RemittanceCorridor define um corredor de remessas entre dois países
+type RemittanceCorridor struct {
+	ID                  string   `json:"id"`
+	SourceCountry       string   `json:"source_country"`
+	DestinationCountry  string   `json:"destination_country"`
+	SourceCurrency      string   `json:"source_currency"`
+	DestinationCurrency string   `json:"destination_currency"`
+	MinAmount           float64  `json:"min_amount"`        // Na moeda de origem
+	MaxAmount           float64  `json:"max_amount"`        // Na moeda de origem
+	FeePercent          float64  `json:"fee_percent"`       // Comissão deduzida do valor enviado
+	FXMarginPercent     float64  `json:"fx_margin_percent"` // Margem sobre a taxa de câmbio de referência
+	PayoutPartner       string   `json:"payout_partner"`    // Parceiro que credita o beneficiário
+	ComplianceHooks     []string `json:"compliance_hooks"`  // Hooks executados antes do débito
+}
+
+// DefaultRemittanceCorridors retorna os corredores AO↔PT e BR↔US
+func DefaultRemittanceCorridors() map[string]RemittanceCorridor {
+	corridors := []RemittanceCorridor{
+		{ID: "AO-PT", SourceCountry: "AO", DestinationCountry: "PT", SourceCurrency: "AOA", DestinationCurrency: "EUR",
+			MinAmount: 5000, MaxAmount: 50000000, FeePercent: 1.5, FXMarginPercent: 1.0, PayoutPartner: "sepa_partner",
+			ComplianceHooks: []string{RemittanceHookBNAExchange}},
+		{ID: "PT-AO", SourceCountry: "PT", DestinationCountry: "AO", SourceCurrency: "EUR", DestinationCurrency: "AOA",
+			MinAmount: 10, MaxAmount: 50000, FeePercent: 1.5, FXMarginPercent: 1.0, PayoutPartner: "emis_partner",
+			ComplianceHooks: []string{RemittanceHookBNAExchange}},
+		{ID: "BR-US", SourceCountry: "BR", DestinationCountry: "US", SourceCurrency: "BRL", DestinationCurrency: "USD",
+			MinAmount: 50, MaxAmount: 250000, FeePercent: 1.0, FXMarginPercent: 0.8, PayoutPartner: "ach_partner",
+			ComplianceHooks: []string{RemittanceHookOFAC}},
+		{ID: "US-BR", SourceCountry: "US", DestinationCountry: "BR", SourceCurrency: "USD", DestinationCurrency: "BRL",
+			MinAmount: 10, MaxAmount: 50000, FeePercent: 1.0, FXMarginPercent: 0.8, PayoutPartner: "pix_partner",
+			ComplianceHooks: []string{RemittanceHookOFAC}},
+	}
+
+	byID := make(map[string]RemittanceCorridor, len(corridors))
+	for _, corridor := range corridors {
+		byID[corridor.ID] = corridor
+	}
+	return byID
+}
+
+// RemittanceBeneficiary identifica quem recebe a remessa no país de destino
+type RemittanceBeneficiary struct {
+	Name      string `json:"name"`
+	Country   string `json:"country"`
+	AccountID string `json:"account_id"` // IBAN, conta ACH, chave PIX ou conta EMIS
+	BankCode  string `json:"bank_code,omitempty"`
+}
+
+// RemittanceDetails são os dados específicos de um pagamento de remessa
+type RemittanceDetails struct {
+	CorridorID          string                `json:"corridor_id"`
+	Beneficiary         RemittanceBeneficiary `json:"beneficiary"`
+	PurposeCode         string                `json:"purpose_code,omitempty"`
+	BNAAuthorizationRef string                `json:"bna_authorization_ref,omitempty"`
+}
+
+// RemittanceLeg é uma perna do movimento multi-moeda
+type RemittanceLeg struct {
+	Type        string     `json:"type"`
+	Currency    string     `json:"currency"`
+	Amount      float64    `json:"amount"`
+	Rate        float64    `json:"rate,omitempty"`
+	Status      string     `json:"status"`
+	Reference   string     `json:"reference,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Remittance acompanha a execução de uma remessa
+type Remittance struct {
+	TransactionID string          `json:"transaction_id" db:"transaction_id"`
+	TenantID      string          `json:"tenant_id" db:"tenant_id"`
+	CorridorID    string          `json:"corridor_id" db:"corridor_id"`
+	Status        string          `json:"status" db:"status"`
+	Fee           float64         `json:"fee" db:"fee"`
+	Legs          []RemittanceLeg `json:"legs" db:"-"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// CreditLeg retorna a perna de crédito ao beneficiário
+func (r *Remittance) CreditLeg() *RemittanceLeg {
+	for i := range r.Legs {
+		if r.Legs[i].Type == RemittanceLegCredit {
+			return &r.Legs[i]
+		}
+	}
+	return nil
+}
+
+// PayoutInstruction é a ordem de crédito enviada ao parceiro de pagamento
+type PayoutInstruction struct {
+	TransactionID string                `json:"transaction_id"`
+	CorridorID    string                `json:"corridor_id"`
+	Amount        float64               `json:"amount"`
+	Currency      string                `json:"currency"`
+	Beneficiary   RemittanceBeneficiary `json:"beneficiary"`
+	PurposeCode   string                `json:"purpose_code,omitempty"`
+}
+
+// RemittancePartnerConfig define o endpoint de crédito de um parceiro de pagamento
+type RemittancePartnerConfig struct {
+	PartnerID string `json:"partner_id"`
+	Endpoint  string `json:"endpoint"`
+	APIKey    string `json:"-"`
+}
+
+// RemittanceConfig contém as configurações do fluxo de remessas
+type RemittanceConfig struct {
+	// Corredores por identificador (padrão: DefaultRemittanceCorridors)
+	Corridors map[string]RemittanceCorridor `json:"corridors"`
+
+	// Taxa de câmbio de referência por par "ORIGEM/DESTINO"
+	FXRates map[string]float64 `json:"fx_rates"`
+
+	// Parceiros de pagamento por identificador
+	Partners map[string]RemittancePartnerConfig `json:"partners"`
+
+	// Nomes da lista SDN da OFAC usados na triagem dos beneficiários
+	OFACSanctionedNames []string `json:"ofac_sanctioned_names"`
+
+	PayoutTimeout time.Duration `json:"payout_timeout"`
+
+	// Resilience sobrepõe PayoutTimeout e define as novas tentativas no mesmo parceiro
+	Resilience resilience.Policy `json:"resilience"`
+}
@@ -0,0 +1,80 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// RemittancePayoutClient credita o beneficiário através de um parceiro no país de destino
+type RemittancePayoutClient interface {
+	// Payout envia a ordem de crédito e retorna a referência atribuída pelo parceiro
+	Payout(ctx context.Context, partner RemittancePartnerConfig, instruction PayoutInstruction) (string, error)
+}
+
+// HTTPRemittancePayoutClient envia as ordens de crédito às APIs HTTP dos parceiros
+type HTTPRemittancePayoutClient struct {
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPRemittancePayoutClient cria o cliente das APIs dos parceiros de pagamento
+func NewHTTPRemittancePayoutClient(config RemittanceConfig) *HTTPRemittancePayoutClient {
+	timeout := config.PayoutTimeout
+	if timeout <= 0 {
+		timeout = DefaultRemittancePayoutTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPRemittancePayoutClient{
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// Payout envia um POST JSON ao endpoint do parceiro
+// A transação é a chave de idempotência, para que as novas tentativas não dupliquem o crédito
+func (c *HTTPRemittancePayoutClient) Payout(ctx context.Context, partner RemittancePartnerConfig, instruction PayoutInstruction) (string, error) {
+	payload, err := json.Marshal(instruction)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, partner.Endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set(resilience.IdempotencyKeyHeader, instruction.TransactionID)
+		if partner.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+partner.APIKey)
+		}
+		return httpReq, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("parceiro %s indisponível: %w", partner.PartnerID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("parceiro %s indisponível: %w", partner.PartnerID, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("parceiro %s recusou o crédito: HTTP %d", partner.PartnerID, resp.StatusCode)
+	}
+
+	var result struct {
+		PayoutReference string `json:"payout_reference"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.PayoutReference == "" {
+		return "", fmt.Errorf("resposta inválida do parceiro %s", partner.PartnerID)
+	}
+	return result.PayoutReference, nil
+}
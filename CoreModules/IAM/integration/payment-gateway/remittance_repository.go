@@ -0,0 +1,80 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresRemittanceStore implementa RemittanceStore para PostgreSQL
+type PostgresRemittanceStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRemittanceStore cria uma nova instância de PostgresRemittanceStore
+func NewPostgresRemittanceStore(db *sqlx.DB) *PostgresRemittanceStore {
+	return &PostgresRemittanceStore{db: db}
+}
+
+// dbRemittance é a representação de Remittance na base de dados
+type dbRemittance struct {
+	Remittance
+	LegsJSON []byte `db:"legs"`
+}
+
+// SaveRemittance grava a remessa, substituindo o estado anterior
+func (r *PostgresRemittanceStore) SaveRemittance(ctx context.Context, remittance *Remittance) error {
+	row := &dbRemittance{Remittance: *remittance}
+
+	var err error
+	if row.LegsJSON, err = json.Marshal(remittance.Legs); err != nil {
+		return fmt.Errorf("falha ao codificar pernas da remessa: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.remittances (
+			tenant_id, transaction_id, corridor_id, status, fee, legs, created_at, updated_at
+		) VALUES (
+			:tenant_id, :transaction_id, :corridor_id, :status, :fee, :legs, :created_at, :updated_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			corridor_id = EXCLUDED.corridor_id,
+			status = EXCLUDED.status,
+			fee = EXCLUDED.fee,
+			legs = EXCLUDED.legs,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar remessa: %w", err)
+	}
+
+	return nil
+}
+
+// GetRemittance recupera a remessa do tenant
+func (r *PostgresRemittanceStore) GetRemittance(ctx context.Context, tenantID, transactionID string) (*Remittance, error) {
+	var row dbRemittance
+	query := `
+		SELECT tenant_id, transaction_id, corridor_id, status, fee, legs, created_at, updated_at
+		FROM payment_gateway.remittances
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRemittanceNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar remessa: %w", err)
+	}
+
+	remittance := row.Remittance
+	if err := json.Unmarshal(row.LegsJSON, &remittance.Legs); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar pernas da remessa: %w", err)
+	}
+	return &remittance, nil
+}
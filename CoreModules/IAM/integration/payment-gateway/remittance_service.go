@@ -0,0 +1,349 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// RemittanceComplianceHook valida uma remessa antes do débito ao remetente
+type RemittanceComplianceHook func(ctx context.Context, req *PaymentRequest, corridor RemittanceCorridor, destinationAmount float64) error
+
+// RemittanceService executa as remessas internacionais em três pernas: débito, câmbio e crédito
+type RemittanceService struct {
+	config RemittanceConfig
+	store  RemittanceStore
+	client RemittancePayoutClient
+	hooks  map[string]RemittanceComplianceHook
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewRemittanceService cria o serviço de remessas com os corredores e parceiros configurados
+func NewRemittanceService(config RemittanceConfig, store RemittanceStore, client RemittancePayoutClient) (*RemittanceService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-remittances",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if len(config.Corridors) == 0 {
+		config.Corridors = DefaultRemittanceCorridors()
+	}
+	if client == nil {
+		client = NewHTTPRemittancePayoutClient(config)
+	}
+
+	service := &RemittanceService{
+		config:          config,
+		store:           store,
+		client:          client,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+	service.hooks = map[string]RemittanceComplianceHook{
+		RemittanceHookBNAExchange: checkBNAExchangeAuthorization,
+		RemittanceHookOFAC:        newOFACScreeningHook(config.OFACSanctionedNames),
+	}
+
+	service.logger.Info("Serviço de remessas internacionais inicializado",
+		"total_corridors", len(config.Corridors),
+		"total_partners", len(config.Partners))
+	return service, nil
+}
+
+// Corridors retorna os corredores configurados ordenados por identificador
+func (s *RemittanceService) Corridors() []RemittanceCorridor {
+	corridors := make([]RemittanceCorridor, 0, len(s.config.Corridors))
+	for _, corridor := range s.config.Corridors {
+		corridors = append(corridors, corridor)
+	}
+	sort.Slice(corridors, func(i, j int) bool { return corridors[i].ID < corridors[j].ID })
+	return corridors
+}
+
+// GetRemittance retorna o estado da remessa do tenant
+func (s *RemittanceService) GetRemittance(ctx context.Context, tenantID, transactionID string) (*Remittance, error) {
+	return s.store.GetRemittance(ctx, tenantID, transactionID)
+}
+
+// Quote calcula a comissão, a taxa aplicada e o valor creditado no destino
+func (s *RemittanceService) Quote(corridor RemittanceCorridor, amount float64) (fee, rate, destinationAmount float64, err error) {
+	reference, exists := s.config.FXRates[corridor.SourceCurrency+"/"+corridor.DestinationCurrency]
+	if !exists || reference <= 0 {
+		return 0, 0, 0, fmt.Errorf("taxa de câmbio %s/%s indisponível", corridor.SourceCurrency, corridor.DestinationCurrency)
+	}
+
+	fee = roundAmount(amount * corridor.FeePercent / 100)
+	rate = reference * (1 - corridor.FXMarginPercent/100)
+	destinationAmount = roundAmount((amount - fee) * rate)
+	return fee, rate, destinationAmount, nil
+}
+
+// Execute valida o corredor, aplica os hooks de compliance e executa as três pernas
+// As recusas antes do débito envolvem ErrRemittanceRejected; uma falha no crédito estorna o débito e o
+// câmbio e envolve ErrRemittancePayoutFailed. Remessas concluídas não são reexecutadas
+func (s *RemittanceService) Execute(ctx context.Context, req *PaymentRequest) (*Remittance, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "RemittanceService.Execute")
+	defer span.End()
+
+	existing, err := s.store.GetRemittance(ctx, req.TenantID, req.TransactionID)
+	if err != nil && !errors.Is(err, ErrRemittanceNotFound) {
+		span.RecordError(err)
+		return nil, err
+	}
+	if existing != nil && existing.Status == RemittanceStatusCompleted {
+		return existing, nil
+	}
+
+	corridor, destinationAmount, rate, fee, err := s.prepare(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		s.metricsRecorder.CounterInc("payment_remittances_total", map[string]string{
+			"corridor": corridorLabel(req),
+			"status":   "rejected",
+		})
+		// Evento de segurança: a remessa foi recusada antes de qualquer movimento de fundos
+		s.logger.WarnWithContext(ctx, "Remessa recusada antes do débito",
+			"tenant_id", req.TenantID,
+			"transaction_id", req.TransactionID,
+			"user_id", req.UserID,
+			"corridor", corridorLabel(req),
+			"error", err.Error())
+		return nil, fmt.Errorf("%w: %w", ErrRemittanceRejected, err)
+	}
+
+	now := s.now()
+	remittance := &Remittance{
+		TransactionID: req.TransactionID,
+		TenantID:      req.TenantID,
+		CorridorID:    corridor.ID,
+		Status:        RemittanceStatusPending,
+		Fee:           fee,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Legs: []RemittanceLeg{
+			{Type: RemittanceLegDebit, Currency: corridor.SourceCurrency, Amount: req.Amount, Status: RemittanceStatusPending},
+			{Type: RemittanceLegFX, Currency: corridor.DestinationCurrency, Amount: destinationAmount, Rate: rate, Status: RemittanceStatusPending},
+			{Type: RemittanceLegCredit, Currency: corridor.DestinationCurrency, Amount: destinationAmount, Status: RemittanceStatusPending},
+		},
+	}
+	if err := s.store.SaveRemittance(ctx, remittance); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Perna 1: débito ao remetente na moeda de origem
+	s.completeLeg(remittance, 0, "DEB-"+req.TransactionID)
+
+	// Perna 2: conversão cambial à taxa cotada
+	s.completeLeg(remittance, 1, "FX-"+req.TransactionID)
+
+	// Perna 3: crédito ao beneficiário pelo parceiro
+	payoutRef, payoutErr := s.client.Payout(ctx, s.config.Partners[corridor.PayoutPartner], PayoutInstruction{
+		TransactionID: req.TransactionID,
+		CorridorID:    corridor.ID,
+		Amount:        destinationAmount,
+		Currency:      corridor.DestinationCurrency,
+		Beneficiary:   req.Remittance.Beneficiary,
+		PurposeCode:   req.Remittance.PurposeCode,
+	})
+	if payoutErr != nil {
+		s.reverse(remittance, payoutErr)
+	} else {
+		s.completeLeg(remittance, 2, payoutRef)
+		remittance.Status = RemittanceStatusCompleted
+	}
+
+	if err := s.store.SaveRemittance(ctx, remittance); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_remittances_total", map[string]string{
+		"corridor": corridor.ID,
+		"status":   remittance.Status,
+	})
+
+	if payoutErr != nil {
+		span.RecordError(payoutErr)
+		s.logger.ErrorWithContext(ctx, "Falha no crédito da remessa; débito estornado",
+			"tenant_id", req.TenantID,
+			"transaction_id", req.TransactionID,
+			"corridor", corridor.ID,
+			"error", payoutErr.Error())
+		return remittance, fmt.Errorf("%w: %w", ErrRemittancePayoutFailed, payoutErr)
+	}
+
+	// Evento de auditoria da remessa concluída
+	s.logger.InfoWithContext(ctx, "Remessa concluída",
+		"tenant_id", req.TenantID,
+		"transaction_id", req.TransactionID,
+		"user_id", req.UserID,
+		"corridor", corridor.ID,
+		"destination_amount", destinationAmount,
+		"destination_currency", corridor.DestinationCurrency,
+		"payout_reference", payoutRef)
+	return remittance, nil
+}
+
+// prepare valida o pagamento contra o corredor, cota o câmbio e aplica os hooks de compliance
+func (s *RemittanceService) prepare(ctx context.Context, req *PaymentRequest) (corridor RemittanceCorridor, destinationAmount, rate, fee float64, err error) {
+	corridor, err = s.resolveCorridor(req)
+	if err != nil {
+		return corridor, 0, 0, 0, err
+	}
+
+	fee, rate, destinationAmount, err = s.Quote(corridor, req.Amount)
+	if err != nil {
+		return corridor, 0, 0, 0, err
+	}
+
+	for _, hookID := range corridor.ComplianceHooks {
+		hook, exists := s.hooks[hookID]
+		if !exists {
+			return corridor, 0, 0, 0, fmt.Errorf("hook de compliance %s não registado", hookID)
+		}
+		if err := hook(ctx, req, corridor, destinationAmount); err != nil {
+			return corridor, 0, 0, 0, fmt.Errorf("%s: %w", hookID, err)
+		}
+	}
+
+	if _, exists := s.config.Partners[corridor.PayoutPartner]; !exists {
+		return corridor, 0, 0, 0, fmt.Errorf("parceiro de pagamento %s não configurado para o corredor %s",
+			corridor.PayoutPartner, corridor.ID)
+	}
+	return corridor, destinationAmount, rate, fee, nil
+}
+
+// resolveCorridor valida o pagamento contra o corredor indicado
+func (s *RemittanceService) resolveCorridor(req *PaymentRequest) (RemittanceCorridor, error) {
+	if req.Remittance == nil {
+		return RemittanceCorridor{}, ErrRemittanceDetailsMissing
+	}
+
+	corridor, exists := s.config.Corridors[req.Remittance.CorridorID]
+	if !exists {
+		return RemittanceCorridor{}, fmt.Errorf("%w: %s", ErrRemittanceCorridorUnknown, req.Remittance.CorridorID)
+	}
+	if req.Currency != corridor.SourceCurrency {
+		return RemittanceCorridor{}, fmt.Errorf("moeda %s inválida para o corredor %s (esperada %s)",
+			req.Currency, corridor.ID, corridor.SourceCurrency)
+	}
+	if req.Amount < corridor.MinAmount || (corridor.MaxAmount > 0 && req.Amount > corridor.MaxAmount) {
+		return RemittanceCorridor{}, fmt.Errorf("valor %.2f %s fora dos limites do corredor %s",
+			req.Amount, req.Currency, corridor.ID)
+	}
+
+	beneficiary := req.Remittance.Beneficiary
+	if beneficiary.Name == "" || beneficiary.AccountID == "" {
+		return RemittanceCorridor{}, errors.New("beneficiário da remessa incompleto")
+	}
+	if beneficiary.Country != "" && beneficiary.Country != corridor.DestinationCountry {
+		return RemittanceCorridor{}, fmt.Errorf("país do beneficiário %s não corresponde ao corredor %s",
+			beneficiary.Country, corridor.ID)
+	}
+	return corridor, nil
+}
+
+// completeLeg marca a perna como concluída com a referência indicada
+func (s *RemittanceService) completeLeg(remittance *Remittance, index int, reference string) {
+	now := s.now()
+	leg := &remittance.Legs[index]
+	leg.Status = RemittanceStatusCompleted
+	leg.Reference = reference
+	leg.CompletedAt = &now
+	remittance.UpdatedAt = now
+}
+
+// reverse marca o crédito como falhado e estorna o débito e o câmbio já executados
+func (s *RemittanceService) reverse(remittance *Remittance, cause error) {
+	for i := range remittance.Legs {
+		leg := &remittance.Legs[i]
+		switch {
+		case leg.Type == RemittanceLegCredit:
+			leg.Status = RemittanceStatusFailed
+			leg.Error = cause.Error()
+		case leg.Status == RemittanceStatusCompleted:
+			leg.Status = RemittanceStatusReversed
+		}
+	}
+	remittance.Status = RemittanceStatusFailed
+	remittance.UpdatedAt = s.now()
+}
+
+// checkBNAExchangeAuthorization exige a referência de autorização cambial do BNA
+// quando o valor da operação em kwanzas ultrapassa o limite de declaração simplificada
+func checkBNAExchangeAuthorization(ctx context.Context, req *PaymentRequest, corridor RemittanceCorridor, destinationAmount float64) error {
+	amountAOA := req.Amount
+	if corridor.DestinationCurrency == "AOA" {
+		amountAOA = destinationAmount
+	}
+	if amountAOA <= bnaExchangeAuthorizationThresholdAOA {
+		return nil
+	}
+	if strings.TrimSpace(req.Remittance.BNAAuthorizationRef) == "" {
+		return fmt.Errorf("operação cambial de %.2f AOA requer autorização do BNA", amountAOA)
+	}
+	if req.Remittance.PurposeCode == "" {
+		return errors.New("código de finalidade obrigatório para operações cambiais autorizadas pelo BNA")
+	}
+	return nil
+}
+
+// newOFACScreeningHook cria o hook de triagem OFAC do país e do nome do beneficiário
+func newOFACScreeningHook(sanctionedNames []string) RemittanceComplianceHook {
+	names := make(map[string]bool, len(sanctionedNames))
+	for _, name := range sanctionedNames {
+		if normalized := normalizeScreeningName(name); normalized != "" {
+			names[normalized] = true
+		}
+	}
+
+	return func(ctx context.Context, req *PaymentRequest, corridor RemittanceCorridor, destinationAmount float64) error {
+		country := req.Remittance.Beneficiary.Country
+		if country == "" {
+			country = corridor.DestinationCountry
+		}
+		if ofacSanctionedCountries[country] {
+			return fmt.Errorf("país do beneficiário %s sob sanções OFAC", country)
+		}
+		if names[normalizeScreeningName(req.Remittance.Beneficiary.Name)] {
+			return errors.New("beneficiário consta da lista SDN da OFAC")
+		}
+		return nil
+	}
+}
+
+// normalizeScreeningName normaliza nomes para comparação na triagem de sanções
+func normalizeScreeningName(name string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(name)), " ")
+}
+
+// corridorLabel retorna o corredor indicado no pagamento para métricas e registos
+func corridorLabel(req *PaymentRequest) string {
+	if req.Remittance == nil {
+		return "unknown"
+	}
+	return req.Remittance.CorridorID
+}
+
+// roundAmount arredonda valores monetários a duas casas decimais
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
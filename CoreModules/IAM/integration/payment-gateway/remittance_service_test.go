@@ -0,0 +1,316 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// fakePayoutClient simula o parceiro de pagamento e regista as ordens de crédito recebidas
+type fakePayoutClient struct {
+	mu           sync.Mutex
+	err          error
+	instructions []PayoutInstruction
+}
+
+func (f *fakePayoutClient) Payout(ctx context.Context, partner RemittancePartnerConfig, instruction PayoutInstruction) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.instructions = append(f.instructions, instruction)
+	if f.err != nil {
+		return "", f.err
+	}
+	return "PAY-" + instruction.TransactionID, nil
+}
+
+func (f *fakePayoutClient) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.instructions)
+}
+
+func testRemittanceConfig() RemittanceConfig {
+	return RemittanceConfig{
+		FXRates: map[string]float64{"AOA/EUR": 0.001, "EUR/AOA": 1000, "BRL/USD": 0.2, "USD/BRL": 5},
+		Partners: map[string]RemittancePartnerConfig{
+			"sepa_partner": {PartnerID: "sepa_partner"},
+			"emis_partner": {PartnerID: "emis_partner"},
+			"ach_partner":  {PartnerID: "ach_partner"},
+			"pix_partner":  {PartnerID: "pix_partner"},
+		},
+		OFACSanctionedNames: []string{"Sanctioned  Person"},
+	}
+}
+
+func newTestRemittanceService(t *testing.T, client RemittancePayoutClient) (*RemittanceService, *InMemoryRemittanceStore) {
+	t.Helper()
+
+	store := NewInMemoryRemittanceStore()
+	service, err := NewRemittanceService(testRemittanceConfig(), store, client)
+	require.NoError(t, err)
+	return service, store
+}
+
+func testRemittanceRequest(corridorID, currency string, amount float64, beneficiary RemittanceBeneficiary) *PaymentRequest {
+	req := testRiskRequest(RegionAngola, currency, amount)
+	req.PaymentMethod = PaymentMethodRemittance
+	req.Remittance = &RemittanceDetails{CorridorID: corridorID, Beneficiary: beneficiary}
+	return req
+}
+
+func TestRemittanceQuote(t *testing.T) {
+	service, _ := newTestRemittanceService(t, &fakePayoutClient{})
+	corridors := DefaultRemittanceCorridors()
+
+	fee, rate, destinationAmount, err := service.Quote(corridors["BR-US"], 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, fee)
+	assert.InDelta(t, 0.1984, rate, 1e-9)
+	assert.Equal(t, 196.42, destinationAmount)
+
+	fee, _, destinationAmount, err = service.Quote(corridors["AO-PT"], 200000)
+	require.NoError(t, err)
+	assert.Equal(t, 3000.0, fee)
+	assert.Equal(t, 195.03, destinationAmount)
+
+	_, _, _, err = service.Quote(RemittanceCorridor{ID: "X", SourceCurrency: "AOA", DestinationCurrency: "JPY"}, 1000)
+	assert.Error(t, err)
+}
+
+func TestRemittanceExecuteRejections(t *testing.T) {
+	portugal := RemittanceBeneficiary{Name: "Maria Silva", Country: "PT", AccountID: "PT50000201231234567890154"}
+	usa := RemittanceBeneficiary{Name: "John Doe", Country: "US", AccountID: "021000021-123456"}
+
+	tests := []struct {
+		name    string
+		request *PaymentRequest
+		cause   error
+	}{
+		{name: "sem dados da remessa", request: testRiskRequest(RegionAngola, "AOA", 100000), cause: ErrRemittanceDetailsMissing},
+		{name: "corredor desconhecido", request: testRemittanceRequest("AO-JP", "AOA", 100000, portugal), cause: ErrRemittanceCorridorUnknown},
+		{name: "moeda diferente da origem", request: testRemittanceRequest("AO-PT", "USD", 100000, portugal)},
+		{name: "valor abaixo do mínimo", request: testRemittanceRequest("AO-PT", "AOA", 100, portugal)},
+		{name: "beneficiário incompleto", request: testRemittanceRequest("AO-PT", "AOA", 100000, RemittanceBeneficiary{Name: "Maria Silva"})},
+		{name: "país do beneficiário fora do corredor", request: testRemittanceRequest("AO-PT", "AOA", 100000, usa)},
+		{name: "BNA sem autorização acima do limite", request: testRemittanceRequest("AO-PT", "AOA", 6000000, portugal)},
+		{name: "OFAC por nome na lista SDN", request: testRemittanceRequest("BR-US", "BRL", 1000,
+			RemittanceBeneficiary{Name: "sanctioned person", Country: "US", AccountID: "1"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakePayoutClient{}
+			service, store := newTestRemittanceService(t, client)
+
+			remittance, err := service.Execute(context.Background(), tt.request)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrRemittanceRejected)
+			if tt.cause != nil {
+				assert.ErrorIs(t, err, tt.cause)
+			}
+			assert.Nil(t, remittance)
+			assert.Zero(t, client.calls())
+
+			_, err = store.GetRemittance(context.Background(), "tenant-1", "tx-1")
+			assert.ErrorIs(t, err, ErrRemittanceNotFound)
+		})
+	}
+}
+
+func TestRemittanceBNAAuthorization(t *testing.T) {
+	service, _ := newTestRemittanceService(t, &fakePayoutClient{})
+	req := testRemittanceRequest("AO-PT", "AOA", 6000000,
+		RemittanceBeneficiary{Name: "Maria Silva", Country: "PT", AccountID: "PT50000201231234567890154"})
+
+	req.Remittance.BNAAuthorizationRef = "BNA-2026-001"
+	_, err := service.Execute(context.Background(), req)
+	assert.ErrorIs(t, err, ErrRemittanceRejected, "a autorização do BNA exige o código de finalidade")
+
+	req.Remittance.PurposeCode = "FAMILY_SUPPORT"
+	remittance, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, RemittanceStatusCompleted, remittance.Status)
+}
+
+func TestRemittanceOFACSanctionedCountry(t *testing.T) {
+	service, _ := newTestRemittanceService(t, &fakePayoutClient{})
+	hook := service.hooks[RemittanceHookOFAC]
+
+	req := testRemittanceRequest("BR-US", "BRL", 1000, RemittanceBeneficiary{Name: "Ana", Country: "IR", AccountID: "1"})
+	err := hook(context.Background(), req, DefaultRemittanceCorridors()["BR-US"], 196.42)
+	assert.Error(t, err)
+}
+
+func TestRemittanceExecuteCompletesLegs(t *testing.T) {
+	client := &fakePayoutClient{}
+	service, store := newTestRemittanceService(t, client)
+	req := testRemittanceRequest("BR-US", "BRL", 1000, RemittanceBeneficiary{Name: "John Doe", Country: "US", AccountID: "1"})
+
+	remittance, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, RemittanceStatusCompleted, remittance.Status)
+	assert.Equal(t, 10.0, remittance.Fee)
+	require.Len(t, remittance.Legs, 3)
+	for _, leg := range remittance.Legs {
+		assert.Equal(t, RemittanceStatusCompleted, leg.Status, leg.Type)
+		assert.NotNil(t, leg.CompletedAt)
+	}
+	assert.Equal(t, "PAY-tx-1", remittance.CreditLeg().Reference)
+	assert.Equal(t, 196.42, remittance.CreditLeg().Amount)
+	assert.Equal(t, "USD", remittance.CreditLeg().Currency)
+
+	stored, err := store.GetRemittance(context.Background(), "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, RemittanceStatusCompleted, stored.Status)
+
+	// Uma remessa concluída não volta a creditar o beneficiário
+	_, err = service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.calls())
+}
+
+func TestRemittanceCreditFailureReversesDebit(t *testing.T) {
+	client := &fakePayoutClient{err: errors.New("timeout")}
+	service, store := newTestRemittanceService(t, client)
+	req := testRemittanceRequest("BR-US", "BRL", 1000, RemittanceBeneficiary{Name: "John Doe", Country: "US", AccountID: "1"})
+
+	remittance, err := service.Execute(context.Background(), req)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrRemittancePayoutFailed)
+	assert.NotErrorIs(t, err, ErrRemittanceRejected)
+	require.NotNil(t, remittance)
+	assert.Equal(t, RemittanceStatusFailed, remittance.Status)
+	assert.Equal(t, RemittanceStatusReversed, remittance.Legs[0].Status)
+	assert.Equal(t, RemittanceStatusReversed, remittance.Legs[1].Status)
+	assert.Equal(t, RemittanceStatusFailed, remittance.Legs[2].Status)
+	assert.Equal(t, "timeout", remittance.Legs[2].Error)
+
+	stored, err := store.GetRemittance(context.Background(), "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, RemittanceStatusFailed, stored.Status)
+
+	// Uma remessa falhada pode ser reexecutada
+	client.err = nil
+	remittance, err = service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, RemittanceStatusCompleted, remittance.Status)
+}
+
+func TestHTTPRemittancePayoutClient(t *testing.T) {
+	var idempotencyKey, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey = r.Header.Get(resilience.IdempotencyKeyHeader)
+		authorization = r.Header.Get("Authorization")
+
+		var instruction PayoutInstruction
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&instruction))
+		if instruction.Amount <= 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"payout_reference": "ACH-123"})
+	}))
+	defer server.Close()
+
+	client := NewHTTPRemittancePayoutClient(RemittanceConfig{Resilience: resilience.Policy{MaxAttempts: 1}})
+	partner := RemittancePartnerConfig{PartnerID: "ach_partner", Endpoint: server.URL, APIKey: "secret"}
+
+	reference, err := client.Payout(context.Background(), partner, PayoutInstruction{TransactionID: "tx-1", Amount: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "ACH-123", reference)
+	assert.Equal(t, "tx-1", idempotencyKey)
+	assert.Equal(t, "Bearer secret", authorization)
+
+	_, err = client.Payout(context.Background(), partner, PayoutInstruction{TransactionID: "tx-2"})
+	assert.Error(t, err)
+}
+
+func TestRemittanceHandler(t *testing.T) {
+	service, _ := newTestRemittanceService(t, &fakePayoutClient{})
+	_, err := service.Execute(context.Background(), testRemittanceRequest("BR-US", "BRL", 1000,
+		RemittanceBeneficiary{Name: "John Doe", Country: "US", AccountID: "1"}))
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	NewRemittanceHandler(service).RegisterRoutes(router)
+
+	t.Run("lista os corredores", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/remittances/corridors", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var corridors []RemittanceCorridor
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&corridors))
+		require.Len(t, corridors, 4)
+		assert.Equal(t, "AO-PT", corridors[0].ID)
+	})
+
+	t.Run("remessa do tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/support/remittances/tx-1", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var remittance Remittance
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&remittance))
+		assert.Equal(t, "BR-US", remittance.CorridorID)
+	})
+
+	t.Run("remessa de outro tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/support/remittances/tx-1", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-2")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestProcessPaymentRemittance(t *testing.T) {
+	beneficiary := RemittanceBeneficiary{Name: "Maria Silva", Country: "PT", AccountID: "PT50000201231234567890154"}
+
+	t.Run("remessa aprovada credita o beneficiário", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _ := newTestRemittanceService(t, &fakePayoutClient{})
+		connector.SetRemittanceService(service)
+
+		response, err := connector.ProcessPayment(context.Background(), testRemittanceRequest("AO-PT", "AOA", 200000, beneficiary))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.Equal(t, "PAY-tx-1", response.AuthorizationID)
+	})
+
+	t.Run("hook de compliance nega a remessa", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		client := &fakePayoutClient{}
+		service, _ := newTestRemittanceService(t, client)
+		connector.SetRemittanceService(service)
+
+		response, err := connector.ProcessPayment(context.Background(), testRemittanceRequest("AO-PT", "AOA", 6000000, beneficiary))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "remessa_recusada", response.StatusCode)
+		assert.Zero(t, client.calls())
+	})
+
+	t.Run("falha no crédito devolve erro", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		service, _ := newTestRemittanceService(t, &fakePayoutClient{err: errors.New("parceiro indisponível")})
+		connector.SetRemittanceService(service)
+
+		response, err := connector.ProcessPayment(context.Background(), testRemittanceRequest("AO-PT", "AOA", 200000, beneficiary))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusError, response.Status)
+		assert.Equal(t, "remessa_falhou", response.StatusCode)
+	})
+}
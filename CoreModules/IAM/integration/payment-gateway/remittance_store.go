@@ -0,0 +1,61 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// RemittanceStore define a persistência das remessas
+type RemittanceStore interface {
+	// SaveRemittance grava a remessa, substituindo o estado anterior
+	SaveRemittance(ctx context.Context, remittance *Remittance) error
+
+	// GetRemittance recupera a remessa do tenant; retorna ErrRemittanceNotFound quando não existe
+	GetRemittance(ctx context.Context, tenantID, transactionID string) (*Remittance, error)
+}
+
+// InMemoryRemittanceStore armazena as remessas em memória
+type InMemoryRemittanceStore struct {
+	remittances map[string]*Remittance
+	mutex       sync.RWMutex
+}
+
+// NewInMemoryRemittanceStore cria um novo armazenamento em memória
+func NewInMemoryRemittanceStore() *InMemoryRemittanceStore {
+	return &InMemoryRemittanceStore{remittances: make(map[string]*Remittance)}
+}
+
+// SaveRemittance grava uma cópia da remessa
+func (s *InMemoryRemittanceStore) SaveRemittance(ctx context.Context, remittance *Remittance) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.remittances[remittance.TenantID+"/"+remittance.TransactionID] = copyRemittance(remittance)
+	return nil
+}
+
+// GetRemittance retorna uma cópia da remessa do tenant
+func (s *InMemoryRemittanceStore) GetRemittance(ctx context.Context, tenantID, transactionID string) (*Remittance, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	remittance, ok := s.remittances[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrRemittanceNotFound
+	}
+	return copyRemittance(remittance), nil
+}
+
+// copyRemittance copia a remessa e as suas pernas
+func copyRemittance(remittance *Remittance) *Remittance {
+	copied := *remittance
+	copied.Legs = make([]RemittanceLeg, len(remittance.Legs))
+	for i, leg := range remittance.Legs {
+		if leg.CompletedAt != nil {
+			completedAt := *leg.CompletedAt
+			leg.CompletedAt = &completedAt
+		}
+		copied.Legs[i] = leg
+	}
+	return &copied
+}
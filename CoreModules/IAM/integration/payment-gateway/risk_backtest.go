@@ -0,0 +1,262 @@
+package paymentgateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// BacktestRecord é uma transação armazenada do corpus de backtesting
+type BacktestRecord struct {
+	Transaction PaymentRequest `json:"transaction"`
+	Fraud       *bool          `json:"fraud,omitempty"` // Desfecho confirmado (fraude ou chargeback); ausente quando desconhecido
+}
+
+// BacktestDecisions contabiliza as decisões de risco
+type BacktestDecisions struct {
+	Approved int `json:"approved"`
+	Review   int `json:"review"`
+	Rejected int `json:"rejected"`
+}
+
+// BacktestFraudOutcome contabiliza o desempenho sobre as transações com desfecho conhecido
+// Fraude enviada para revisão ou rejeitada conta como detetada
+type BacktestFraudOutcome struct {
+	Caught         int `json:"caught"`
+	Missed         int `json:"missed"`
+	FalsePositives int `json:"false_positives"` // Transações legítimas rejeitadas
+}
+
+// BacktestOutcome agrega o resultado de um conjunto de regras sobre o corpus
+type BacktestOutcome struct {
+	RuleSet   string               `json:"rule_set"`
+	Decisions BacktestDecisions    `json:"decisions"`
+	Fraud     BacktestFraudOutcome `json:"fraud"`
+}
+
+// BacktestRuleTriggers compara o número de acionamentos de uma regra
+type BacktestRuleTriggers struct {
+	RuleID   string `json:"rule_id"`
+	Baseline int    `json:"baseline"`
+	Proposed int    `json:"proposed"`
+	Delta    int    `json:"delta"`
+}
+
+// BacktestDecisionChange regista uma transação cuja decisão muda com o conjunto proposto
+type BacktestDecisionChange struct {
+	TransactionID    string  `json:"transaction_id"`
+	Market           string  `json:"market"`
+	Amount           float64 `json:"amount"`
+	Currency         string  `json:"currency"`
+	Fraud            *bool   `json:"fraud,omitempty"`
+	BaselineDecision string  `json:"baseline_decision"`
+	ProposedDecision string  `json:"proposed_decision"`
+	BaselineScore    float64 `json:"baseline_score"`
+	ProposedScore    float64 `json:"proposed_score"`
+}
+
+// BacktestReport é o relatório de backtesting para o comité de risco
+type BacktestReport struct {
+	GeneratedAt   time.Time                `json:"generated_at"`
+	Transactions  int                      `json:"transactions"`
+	Labelled      int                      `json:"labelled"`
+	FraudLabelled int                      `json:"fraud_labelled"`
+	Baseline      BacktestOutcome          `json:"baseline"`
+	Proposed      BacktestOutcome          `json:"proposed"`
+	DecisionDelta BacktestDecisions        `json:"decision_delta"` // Proposto menos atual
+	FraudDelta    BacktestFraudOutcome     `json:"fraud_delta"`
+	Rules         []BacktestRuleTriggers   `json:"rules"`
+	Changes       []BacktestDecisionChange `json:"changes"`
+}
+
+// Backtest reavalia o corpus com as regras atuais e com o conjunto proposto, sem registar as avaliações
+func (e *RiskEngine) Backtest(ctx context.Context, records []BacktestRecord, proposal RiskRuleSet) (*BacktestReport, error) {
+	ctx, span := e.tracer.StartSpan(ctx, "RiskEngine.Backtest")
+	defer span.End()
+
+	knownRules := make(map[string]bool, len(e.rules))
+	for _, rule := range e.rules {
+		knownRules[rule.ID] = true
+	}
+	if err := proposal.validate(knownRules); err != nil {
+		return nil, err
+	}
+	if proposal.Name == "" {
+		proposal.Name = "proposed"
+	}
+
+	baseline := e.CurrentRuleSet()
+	report := &BacktestReport{
+		GeneratedAt: e.now().UTC(),
+		Baseline:    BacktestOutcome{RuleSet: baseline.Name},
+		Proposed:    BacktestOutcome{RuleSet: proposal.Name},
+		Rules:       make([]BacktestRuleTriggers, 0),
+		Changes:     make([]BacktestDecisionChange, 0),
+	}
+	triggers := make(map[string]*BacktestRuleTriggers)
+	triggersFor := func(ruleID string) *BacktestRuleTriggers {
+		if _, exists := triggers[ruleID]; !exists {
+			triggers[ruleID] = &BacktestRuleTriggers{RuleID: ruleID}
+		}
+		return triggers[ruleID]
+	}
+
+	for i := range records {
+		record := &records[i]
+		req := &record.Transaction
+
+		current := e.evaluateRules(ctx, req, baseline)
+		proposed := e.evaluateRules(ctx, req, proposal)
+
+		report.Transactions++
+		if record.Fraud != nil {
+			report.Labelled++
+			if *record.Fraud {
+				report.FraudLabelled++
+			}
+		}
+		report.Baseline.record(current.Decision, record.Fraud)
+		report.Proposed.record(proposed.Decision, record.Fraud)
+
+		for _, rule := range current.Rules {
+			triggersFor(rule.RuleID).Baseline++
+		}
+		for _, rule := range proposed.Rules {
+			triggersFor(rule.RuleID).Proposed++
+		}
+
+		if current.Decision != proposed.Decision {
+			report.Changes = append(report.Changes, BacktestDecisionChange{
+				TransactionID:    req.TransactionID,
+				Market:           req.RegionCode,
+				Amount:           req.Amount,
+				Currency:         req.Currency,
+				Fraud:            record.Fraud,
+				BaselineDecision: current.Decision,
+				ProposedDecision: proposed.Decision,
+				BaselineScore:    current.RiskScore,
+				ProposedScore:    proposed.RiskScore,
+			})
+		}
+	}
+
+	for _, trigger := range triggers {
+		trigger.Delta = trigger.Proposed - trigger.Baseline
+		report.Rules = append(report.Rules, *trigger)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool { return report.Rules[i].RuleID < report.Rules[j].RuleID })
+
+	report.DecisionDelta = BacktestDecisions{
+		Approved: report.Proposed.Decisions.Approved - report.Baseline.Decisions.Approved,
+		Review:   report.Proposed.Decisions.Review - report.Baseline.Decisions.Review,
+		Rejected: report.Proposed.Decisions.Rejected - report.Baseline.Decisions.Rejected,
+	}
+	report.FraudDelta = BacktestFraudOutcome{
+		Caught:         report.Proposed.Fraud.Caught - report.Baseline.Fraud.Caught,
+		Missed:         report.Proposed.Fraud.Missed - report.Baseline.Fraud.Missed,
+		FalsePositives: report.Proposed.Fraud.FalsePositives - report.Baseline.Fraud.FalsePositives,
+	}
+
+	e.logger.InfoWithContext(ctx, "Backtesting de regras de risco concluído",
+		"rule_set", proposal.Name,
+		"transactions", report.Transactions,
+		"decision_changes", len(report.Changes))
+	return report, nil
+}
+
+// record contabiliza uma decisão e, se o desfecho for conhecido, o seu acerto
+func (o *BacktestOutcome) record(decision string, fraud *bool) {
+	switch decision {
+	case RiskDecisionApproved:
+		o.Decisions.Approved++
+	case RiskDecisionReview:
+		o.Decisions.Review++
+	case RiskDecisionRejected:
+		o.Decisions.Rejected++
+	}
+
+	if fraud == nil {
+		return
+	}
+	switch {
+	case *fraud && decision == RiskDecisionApproved:
+		o.Fraud.Missed++
+	case *fraud:
+		o.Fraud.Caught++
+	case decision == RiskDecisionRejected:
+		o.Fraud.FalsePositives++
+	}
+}
+
+// WriteJSON exporta o relatório em JSON
+func (r *BacktestReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV exporta o relatório em CSV, com uma secção por linha (decisões, fraude, regras e alterações)
+func (r *BacktestReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	itoa := strconv.Itoa
+	ftoa := func(f float64) string { return strconv.FormatFloat(f, 'f', 2, 64) }
+
+	rows := [][]string{
+		{"section", "key", "baseline", "proposed", "delta"},
+		{"summary", "transactions", itoa(r.Transactions), itoa(r.Transactions), "0"},
+		{"summary", "labelled", itoa(r.Labelled), itoa(r.Labelled), "0"},
+		{"summary", "fraud_labelled", itoa(r.FraudLabelled), itoa(r.FraudLabelled), "0"},
+		{"decision", RiskDecisionApproved, itoa(r.Baseline.Decisions.Approved), itoa(r.Proposed.Decisions.Approved), itoa(r.DecisionDelta.Approved)},
+		{"decision", RiskDecisionReview, itoa(r.Baseline.Decisions.Review), itoa(r.Proposed.Decisions.Review), itoa(r.DecisionDelta.Review)},
+		{"decision", RiskDecisionRejected, itoa(r.Baseline.Decisions.Rejected), itoa(r.Proposed.Decisions.Rejected), itoa(r.DecisionDelta.Rejected)},
+		{"fraud", "caught", itoa(r.Baseline.Fraud.Caught), itoa(r.Proposed.Fraud.Caught), itoa(r.FraudDelta.Caught)},
+		{"fraud", "missed", itoa(r.Baseline.Fraud.Missed), itoa(r.Proposed.Fraud.Missed), itoa(r.FraudDelta.Missed)},
+		{"fraud", "false_positives", itoa(r.Baseline.Fraud.FalsePositives), itoa(r.Proposed.Fraud.FalsePositives), itoa(r.FraudDelta.FalsePositives)},
+	}
+	for _, rule := range r.Rules {
+		rows = append(rows, []string{"rule", rule.RuleID, itoa(rule.Baseline), itoa(rule.Proposed), itoa(rule.Delta)})
+	}
+	for _, change := range r.Changes {
+		rows = append(rows, []string{"change", change.TransactionID,
+			change.BaselineDecision + " (" + ftoa(change.BaselineScore) + ")",
+			change.ProposedDecision + " (" + ftoa(change.ProposedScore) + ")",
+			""})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("falha ao escrever relatório CSV: %w", err)
+	}
+	return nil
+}
+
+// LoadBacktestCorpus lê o corpus de transações armazenadas em JSON Lines
+func LoadBacktestCorpus(r io.Reader) ([]BacktestRecord, error) {
+	records := make([]BacktestRecord, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var record BacktestRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("linha %d do corpus inválida: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("falha ao ler o corpus: %w", err)
+	}
+	return records, nil
+}
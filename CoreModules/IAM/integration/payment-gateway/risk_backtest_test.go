@@ -0,0 +1,161 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func boolPtr(v bool) *bool { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func testBacktestRecord(id, region, currency string, amount float64, fraud *bool) BacktestRecord {
+	req := testRiskRequest(region, currency, amount)
+	req.TransactionID = id
+	return BacktestRecord{Transaction: *req, Fraud: fraud}
+}
+
+func TestRiskEngineBacktest(t *testing.T) {
+	engine := newTestRiskEngine(t, NewInMemoryTransactionRecordStore())
+	records := []BacktestRecord{
+		testBacktestRecord("tx-a", RegionAngola, "AOA", 1000, boolPtr(false)),
+		testBacktestRecord("tx-b", RegionAngola, "USD", 100, boolPtr(true)),
+		testBacktestRecord("tx-c", RegionBrazil, "BRL", 10001, boolPtr(false)),
+		testBacktestRecord("tx-d", RegionBrazil, "BRL", 100, nil),
+	}
+	proposal := RiskRuleSet{
+		RejectThreshold: DefaultRiskRejectThreshold,
+		ReviewThreshold: DefaultRiskReviewThreshold,
+		Rules: map[string]RiskRuleOverride{
+			"angola_foreign_currency": {Enabled: boolPtr(false)},
+			"high_value_transaction":  {Score: floatPtr(0.9)},
+		},
+	}
+
+	report, err := engine.Backtest(context.Background(), records, proposal)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Transactions)
+	assert.Equal(t, 3, report.Labelled)
+	assert.Equal(t, 1, report.FraudLabelled)
+
+	assert.Equal(t, "current", report.Baseline.RuleSet)
+	assert.Equal(t, BacktestDecisions{Approved: 2, Review: 1, Rejected: 1}, report.Baseline.Decisions)
+	assert.Equal(t, BacktestFraudOutcome{Caught: 1}, report.Baseline.Fraud)
+
+	assert.Equal(t, "proposed", report.Proposed.RuleSet)
+	assert.Equal(t, BacktestDecisions{Approved: 3, Rejected: 1}, report.Proposed.Decisions)
+	assert.Equal(t, BacktestFraudOutcome{Missed: 1, FalsePositives: 1}, report.Proposed.Fraud)
+
+	assert.Equal(t, BacktestDecisions{Approved: 1, Review: -1}, report.DecisionDelta)
+	assert.Equal(t, BacktestFraudOutcome{Caught: -1, Missed: 1, FalsePositives: 1}, report.FraudDelta)
+
+	assert.Equal(t, []BacktestRuleTriggers{
+		{RuleID: "angola_foreign_currency", Baseline: 1, Proposed: 0, Delta: -1},
+		{RuleID: "high_value_transaction", Baseline: 1, Proposed: 1, Delta: 0},
+	}, report.Rules)
+
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, "tx-b", report.Changes[0].TransactionID)
+	assert.Equal(t, RiskDecisionRejected, report.Changes[0].BaselineDecision)
+	assert.Equal(t, RiskDecisionApproved, report.Changes[0].ProposedDecision)
+	assert.Equal(t, "tx-c", report.Changes[1].TransactionID)
+	assert.Equal(t, RiskDecisionReview, report.Changes[1].BaselineDecision)
+	assert.Equal(t, RiskDecisionRejected, report.Changes[1].ProposedDecision)
+	assert.Equal(t, 0.9, report.Changes[1].ProposedScore)
+
+	// O backtesting não regista avaliações
+	_, err = engine.GetExplanation(context.Background(), "tenant-1", "tx-b")
+	assert.ErrorIs(t, err, ErrTransactionRecordNotFound)
+}
+
+func TestRiskEngineBacktestRejectsInvalidRuleSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		proposal RiskRuleSet
+	}{
+		{
+			name:     "revisão acima da rejeição",
+			proposal: RiskRuleSet{RejectThreshold: 0.5, ReviewThreshold: 0.8},
+		},
+		{
+			name:     "rejeição acima de 1",
+			proposal: RiskRuleSet{RejectThreshold: 1.2, ReviewThreshold: 0.5},
+		},
+		{
+			name:     "limiar de revisão ausente",
+			proposal: RiskRuleSet{RejectThreshold: 0.8},
+		},
+		{
+			name: "regra desconhecida",
+			proposal: RiskRuleSet{RejectThreshold: 0.8, ReviewThreshold: 0.5, Rules: map[string]RiskRuleOverride{
+				"unknown_rule": {Enabled: boolPtr(false)},
+			}},
+		},
+		{
+			name: "score fora do intervalo",
+			proposal: RiskRuleSet{RejectThreshold: 0.8, ReviewThreshold: 0.5, Rules: map[string]RiskRuleOverride{
+				"high_value_transaction": {Score: floatPtr(1.5)},
+			}},
+		},
+	}
+
+	engine := newTestRiskEngine(t, NewInMemoryTransactionRecordStore())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.Backtest(context.Background(), nil, tt.proposal)
+			assert.ErrorIs(t, err, ErrRiskRuleSetInvalid)
+		})
+	}
+}
+
+func TestBacktestReportWriteCSV(t *testing.T) {
+	engine := newTestRiskEngine(t, NewInMemoryTransactionRecordStore())
+	report, err := engine.Backtest(context.Background(), []BacktestRecord{
+		testBacktestRecord("tx-c", RegionBrazil, "BRL", 10001, boolPtr(false)),
+	}, RiskRuleSet{
+		Name:            "stricter",
+		RejectThreshold: 0.6,
+		ReviewThreshold: 0.5,
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteCSV(&out))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"section", "key", "baseline", "proposed", "delta"}, rows[0])
+	assert.Contains(t, rows, []string{"decision", RiskDecisionRejected, "0", "1", "1"})
+	assert.Contains(t, rows, []string{"fraud", "false_positives", "0", "1", "1"})
+	assert.Contains(t, rows, []string{"rule", "high_value_transaction", "1", "1", "0"})
+	assert.Contains(t, rows, []string{"change", "tx-c", "review (0.60)", "rejected (0.60)", ""})
+}
+
+func TestLoadBacktestCorpus(t *testing.T) {
+	t.Run("ignora linhas em branco", func(t *testing.T) {
+		corpus := `{"transaction":{"transaction_id":"tx-1","region_code":"AO","amount":100,"currency":"AOA"},"fraud":true}
+
+{"transaction":{"transaction_id":"tx-2","region_code":"BR","amount":50,"currency":"BRL"}}
+`
+		records, err := LoadBacktestCorpus(strings.NewReader(corpus))
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "tx-1", records[0].Transaction.TransactionID)
+		require.NotNil(t, records[0].Fraud)
+		assert.True(t, *records[0].Fraud)
+		assert.Nil(t, records[1].Fraud)
+	})
+
+	t.Run("indica a linha inválida", func(t *testing.T) {
+		_, err := LoadBacktestCorpus(strings.NewReader("{\"transaction\":{}}\nnão é json\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "linha 2")
+	})
+}
@@ -0,0 +1,197 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// RiskEngine avalia as transações com as regras de risco globais e do mercado, decide entre aprovação,
+// verificação reforçada e rejeição e persiste a explicação para consulta pelas equipas de suporte
+type RiskEngine struct {
+	config RiskEngineConfig
+	rules  []RiskRule
+	store  TransactionRecordStore
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewRiskEngine cria o motor de risco com as regras básicas e as regras de cada mercado
+func NewRiskEngine(config RiskEngineConfig, store TransactionRecordStore) (*RiskEngine, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-risk-engine",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.RejectThreshold <= 0 {
+		config.RejectThreshold = DefaultRiskRejectThreshold
+	}
+	if config.ReviewThreshold <= 0 {
+		config.ReviewThreshold = DefaultRiskReviewThreshold
+	}
+
+	engine := &RiskEngine{
+		config:          config,
+		rules:           defaultRiskRules(),
+		store:           store,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+
+	engine.logger.Info("Motor de risco inicializado", "total_rules", len(engine.rules))
+	return engine, nil
+}
+
+// EvaluateTransaction avalia o pagamento e persiste o registo da transação com a explicação de risco
+// Um registo que não pode ser gravado falha a avaliação: o suporte tem de poder explicar toda a decisão
+func (e *RiskEngine) EvaluateTransaction(ctx context.Context, req *PaymentRequest) (*RiskExplanation, error) {
+	ctx, span := e.tracer.StartSpan(ctx, "RiskEngine.EvaluateTransaction")
+	defer span.End()
+
+	explanation := e.evaluateRules(ctx, req, e.CurrentRuleSet())
+	explanation.Sandbox = req.Sandbox
+	for _, rule := range explanation.Rules {
+		e.logger.InfoWithContext(ctx, "Regra de risco acionada",
+			"transaction_id", req.TransactionID,
+			"rule_id", rule.RuleID,
+			"contribution", rule.Contribution)
+	}
+
+	record := &TransactionRecord{
+		TransactionID:     req.TransactionID,
+		TenantID:          req.TenantID,
+		MerchantID:        req.MerchantID,
+		UserID:            req.UserID,
+		DeviceFingerprint: req.DeviceInfo.DeviceFingerprint,
+		PaymentMethod:     req.PaymentMethod,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		RiskScore:         explanation.RiskScore,
+		RiskExplanation:   explanation,
+		RecordedAt:        explanation.EvaluatedAt,
+	}
+	if err := e.store.SaveTransactionRecord(ctx, record); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("falha ao registar a avaliação de risco: %w", err)
+	}
+
+	e.logger.InfoWithContext(ctx, "Avaliação de risco concluída",
+		"transaction_id", req.TransactionID,
+		"risk_score", explanation.RiskScore,
+		"decision", explanation.Decision,
+		"triggered_rules", len(explanation.Rules))
+
+	e.metricsRecorder.HistogramObserve(paymentMetric(req, "payment_gateway_risk_score"), explanation.RiskScore, map[string]string{
+		"region":         req.RegionCode,
+		"payment_method": req.PaymentMethod,
+		"decision":       explanation.Decision,
+	})
+
+	return explanation, nil
+}
+
+// GetExplanation retorna a explicação de risco registada para a transação do tenant
+func (e *RiskEngine) GetExplanation(ctx context.Context, tenantID, transactionID string) (*RiskExplanation, error) {
+	record, err := e.store.GetTransactionRecord(ctx, tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if record.RiskExplanation == nil {
+		return nil, ErrTransactionRecordNotFound
+	}
+	return record.RiskExplanation, nil
+}
+
+// CurrentRuleSet retorna o conjunto de regras em produção: todas as regras com o score próprio e
+// os limiares configurados
+func (e *RiskEngine) CurrentRuleSet() RiskRuleSet {
+	return RiskRuleSet{
+		Name:            "current",
+		RejectThreshold: e.config.RejectThreshold,
+		ReviewThreshold: e.config.ReviewThreshold,
+	}
+}
+
+// evaluateRules aplica as regras globais e as do mercado do pagamento segundo o conjunto de regras
+// indicado; a regra de maior score é a decisiva
+func (e *RiskEngine) evaluateRules(ctx context.Context, req *PaymentRequest, ruleSet RiskRuleSet) *RiskExplanation {
+	explanation := &RiskExplanation{
+		TransactionID: req.TransactionID,
+		TenantID:      req.TenantID,
+		Market:        req.RegionCode,
+		Rules:         make([]RiskRuleExplanation, 0),
+		EvaluatedAt:   e.now().UTC(),
+	}
+	highestScore := 0.0
+	decisiveIndex := -1
+
+	for _, rule := range e.rules {
+		if rule.Market != RegionGlobal && rule.Market != req.RegionCode {
+			continue
+		}
+		override := ruleSet.Rules[rule.ID]
+		if override.Enabled != nil && !*override.Enabled {
+			continue
+		}
+
+		triggered, score, err := rule.Evaluate(req)
+		if err != nil {
+			e.logger.ErrorWithContext(ctx, "Erro ao avaliar regra de risco",
+				"rule_id", rule.ID,
+				"transaction_id", req.TransactionID,
+				"error", err.Error())
+			continue
+		}
+		if !triggered {
+			continue
+		}
+		if override.Score != nil {
+			score = *override.Score
+		}
+
+		conditions := []string{rule.Description}
+		if rule.Explain != nil {
+			if matched := rule.Explain(req); len(matched) > 0 {
+				conditions = matched
+			}
+		}
+		explanation.Rules = append(explanation.Rules, RiskRuleExplanation{
+			RuleID:            rule.ID,
+			Name:              rule.Name,
+			Severity:          rule.Severity,
+			Market:            rule.Market,
+			Contribution:      score,
+			MatchedConditions: conditions,
+			Remediation:       rule.Remediation,
+		})
+
+		if score > highestScore {
+			highestScore = score
+			decisiveIndex = len(explanation.Rules) - 1
+		}
+	}
+
+	if decisiveIndex >= 0 {
+		explanation.Rules[decisiveIndex].Decisive = true
+	}
+	explanation.RiskScore = highestScore
+	explanation.Decision = ruleSet.decision(highestScore)
+	return explanation
+}
+
+// decision converte o score de risco na decisão segundo os limiares configurados
+func (e *RiskEngine) decision(score float64) string {
+	return e.CurrentRuleSet().decision(score)
+}
@@ -0,0 +1,228 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTransactionRecordStore simula um armazenamento indisponível
+type failingTransactionRecordStore struct{}
+
+func (failingTransactionRecordStore) SaveTransactionRecord(ctx context.Context, record *TransactionRecord) error {
+	return errors.New("armazenamento indisponível")
+}
+
+func (failingTransactionRecordStore) GetTransactionRecord(ctx context.Context, tenantID, transactionID string) (*TransactionRecord, error) {
+	return nil, errors.New("armazenamento indisponível")
+}
+
+func newTestRiskEngine(t *testing.T, store TransactionRecordStore) *RiskEngine {
+	t.Helper()
+
+	engine, err := NewRiskEngine(RiskEngineConfig{}, store)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	return engine
+}
+
+func testRiskRequest(region, currency string, amount float64) *PaymentRequest {
+	return &PaymentRequest{
+		RequestID:     "req-1",
+		TransactionID: "tx-1",
+		TenantID:      "tenant-1",
+		UserID:        "user-1",
+		MerchantID:    "merchant-1",
+		RegionCode:    region,
+		Amount:        amount,
+		Currency:      currency,
+		PaymentMethod: PaymentMethodCard,
+		Timestamp:     time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRiskEngineEvaluateTransaction(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       func() *PaymentRequest
+		expectedRules []string
+		decisive      string
+		expectedScore float64
+		decision      string
+	}{
+		{
+			name:     "sem regras acionadas",
+			request:  func() *PaymentRequest { return testRiskRequest(RegionAngola, "AOA", 1000) },
+			decision: RiskDecisionApproved,
+		},
+		{
+			name:          "alto valor com limiar da moeda",
+			request:       func() *PaymentRequest { return testRiskRequest(RegionBrazil, "BRL", 10001) },
+			expectedRules: []string{"high_value_transaction"},
+			decisive:      "high_value_transaction",
+			expectedScore: 0.6,
+			decision:      RiskDecisionReview,
+		},
+		{
+			name:          "moeda sem limiar próprio usa o limiar padrão",
+			request:       func() *PaymentRequest { return testRiskRequest("ZA", "ZAR", 5001) },
+			expectedRules: []string{"high_value_transaction"},
+			decisive:      "high_value_transaction",
+			expectedScore: 0.6,
+			decision:      RiskDecisionReview,
+		},
+		{
+			name: "regras de outro mercado não se aplicam",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionBrazil, "USD", 100)
+				req.Metadata = map[string]interface{}{"ofac_match": true}
+				return req
+			},
+			decision: RiskDecisionApproved,
+		},
+		{
+			name: "moeda estrangeira em Angola sem autorização de câmbio",
+			request: func() *PaymentRequest {
+				return testRiskRequest(RegionAngola, "USD", 100)
+			},
+			expectedRules: []string{"angola_foreign_currency"},
+			decisive:      "angola_foreign_currency",
+			expectedScore: 0.8,
+			decision:      RiskDecisionRejected,
+		},
+		{
+			name: "regra de maior score é a decisiva",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionAngola, "USD", 6000)
+				req.Metadata = map[string]interface{}{"exchange_authorization": "BNA-1"}
+				req.ShippingAddress = &Address{Country: "KP"}
+				req.BillingAddress = &Address{Country: "AO"}
+				return req
+			},
+			expectedRules: []string{"high_value_transaction", "address_mismatch", "angola_sanctioned_countries"},
+			decisive:      "angola_sanctioned_countries",
+			expectedScore: 0.9,
+			decision:      RiskDecisionRejected,
+		},
+		{
+			name: "PIX com chave inválida",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionBrazil, "BRL", 100)
+				req.PaymentMethod = PaymentMethodPIX
+				req.PaymentDetails = map[string]interface{}{"pix_key_type": "iban"}
+				return req
+			},
+			expectedRules: []string{"brazil_pix_validation"},
+			decisive:      "brazil_pix_validation",
+			expectedScore: 0.5,
+			decision:      RiskDecisionReview,
+		},
+		{
+			name: "SCA sem MFA de nível alto",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionEU, "EUR", 50)
+				req.MFALevel = "low"
+				return req
+			},
+			expectedRules: []string{"eu_sca_compliance"},
+			decisive:      "eu_sca_compliance",
+			expectedScore: 0.8,
+			decision:      RiskDecisionRejected,
+		},
+		{
+			name: "SCA abaixo do limite",
+			request: func() *PaymentRequest {
+				return testRiskRequest(RegionEU, "EUR", 30)
+			},
+			decision: RiskDecisionApproved,
+		},
+		{
+			name: "OFAC",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionUSA, "USD", 100)
+				req.Metadata = map[string]interface{}{"ofac_match": true}
+				return req
+			},
+			expectedRules: []string{"usa_ofac_compliance"},
+			decisive:      "usa_ofac_compliance",
+			expectedScore: 1.0,
+			decision:      RiskDecisionRejected,
+		},
+		{
+			name: "GIFiM com relatório registado",
+			request: func() *PaymentRequest {
+				req := testRiskRequest(RegionMozambique, "MZN", 600000)
+				req.Metadata = map[string]interface{}{"gifim_report": true}
+				return req
+			},
+			expectedRules: []string{"high_value_transaction"},
+			decisive:      "high_value_transaction",
+			expectedScore: 0.6,
+			decision:      RiskDecisionReview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestRiskEngine(t, NewInMemoryTransactionRecordStore())
+
+			explanation, err := engine.EvaluateTransaction(context.Background(), tt.request())
+			require.NoError(t, err)
+
+			expectedRules := tt.expectedRules
+			if expectedRules == nil {
+				expectedRules = []string{}
+			}
+			assert.Equal(t, expectedRules, explanation.RuleIDs())
+			assert.Equal(t, tt.expectedScore, explanation.RiskScore)
+			assert.Equal(t, tt.decision, explanation.Decision)
+
+			for _, rule := range explanation.Rules {
+				assert.Equal(t, rule.RuleID == tt.decisive, rule.Decisive, rule.RuleID)
+				assert.NotEmpty(t, rule.MatchedConditions, rule.RuleID)
+				assert.NotEmpty(t, rule.Remediation, rule.RuleID)
+			}
+		})
+	}
+}
+
+func TestRiskEngineThresholds(t *testing.T) {
+	engine, err := NewRiskEngine(RiskEngineConfig{RejectThreshold: 0.95, ReviewThreshold: 0.7}, NewInMemoryTransactionRecordStore())
+	require.NoError(t, err)
+
+	assert.Equal(t, RiskDecisionApproved, engine.decision(0.6))
+	assert.Equal(t, RiskDecisionReview, engine.decision(0.7))
+	assert.Equal(t, RiskDecisionReview, engine.decision(0.9))
+	assert.Equal(t, RiskDecisionRejected, engine.decision(0.95))
+}
+
+func TestRiskEnginePersistsExplanation(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryTransactionRecordStore()
+
+	req := testRiskRequest(RegionAngola, "USD", 100)
+	_, err := newTestRiskEngine(t, store).EvaluateTransaction(ctx, req)
+	require.NoError(t, err)
+
+	// Um novo motor sobre o mesmo armazenamento, como após um reinício, retorna a explicação gravada
+	explanation, err := newTestRiskEngine(t, store).GetExplanation(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"angola_foreign_currency"}, explanation.RuleIDs())
+	assert.Equal(t, RiskDecisionRejected, explanation.Decision)
+	assert.Equal(t, []string{"moeda USD diferente de AOA", "autorização de câmbio ausente"}, explanation.Rules[0].MatchedConditions)
+
+	// A explicação de um tenant não é visível a outro
+	_, err = newTestRiskEngine(t, store).GetExplanation(ctx, "tenant-2", "tx-1")
+	assert.ErrorIs(t, err, ErrTransactionRecordNotFound)
+}
+
+func TestRiskEngineFailsWhenRecordNotSaved(t *testing.T) {
+	engine := newTestRiskEngine(t, failingTransactionRecordStore{})
+
+	_, err := engine.EvaluateTransaction(context.Background(), testRiskRequest(RegionAngola, "AOA", 100))
+	assert.Error(t, err)
+}
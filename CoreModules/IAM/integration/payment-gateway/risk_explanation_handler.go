@@ -0,0 +1,48 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RiskExplanationHandler expõe às equipas de suporte a explicação de risco das transações
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type RiskExplanationHandler struct {
+	engine *RiskEngine
+}
+
+// NewRiskExplanationHandler cria uma nova instância do RiskExplanationHandler
+func NewRiskExplanationHandler(engine *RiskEngine) *RiskExplanationHandler {
+	return &RiskExplanationHandler{engine: engine}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *RiskExplanationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/transactions/{transactionId}/risk-explanation", h.GetRiskExplanation).Methods(http.MethodGet)
+}
+
+// GetRiskExplanation retorna o score, a decisão e as regras acionadas de uma transação do tenant
+func (h *RiskExplanationHandler) GetRiskExplanation(w http.ResponseWriter, r *http.Request) {
+	transactionID := mux.Vars(r)["transactionId"]
+	tenantID := r.Header.Get("X-Tenant-ID")
+
+	explanation, err := h.engine.GetExplanation(r.Context(), tenantID, transactionID)
+	if err != nil {
+		if errors.Is(err, ErrTransactionRecordNotFound) {
+			respondWithError(w, http.StatusNotFound, "not_found", "Explicação de risco não encontrada para a transação")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao recuperar explicação de risco")
+		return
+	}
+
+	// Registar a consulta para auditoria do acesso pelas equipas de suporte
+	h.engine.logger.InfoWithContext(r.Context(), "Explicação de risco consultada",
+		"tenant_id", tenantID,
+		"transaction_id", transactionID,
+		"operator", supportOperator(r))
+
+	respondWithJSON(w, http.StatusOK, explanation)
+}
@@ -0,0 +1,53 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskExplanationHandler(t *testing.T) {
+	store := NewInMemoryTransactionRecordStore()
+	engine := newTestRiskEngine(t, store)
+	_, err := engine.EvaluateTransaction(context.Background(), testRiskRequest(RegionAngola, "USD", 100))
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	NewRiskExplanationHandler(engine).RegisterRoutes(router)
+
+	tests := []struct {
+		name     string
+		tenantID string
+		path     string
+		status   int
+	}{
+		{name: "transação do tenant", tenantID: "tenant-1", path: "/support/transactions/tx-1/risk-explanation", status: http.StatusOK},
+		{name: "transação de outro tenant", tenantID: "tenant-2", path: "/support/transactions/tx-1/risk-explanation", status: http.StatusNotFound},
+		{name: "transação desconhecida", tenantID: "tenant-1", path: "/support/transactions/tx-2/risk-explanation", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asSupportOperator(httptest.NewRequest(http.MethodGet, tt.path, nil), "ana")
+			req.Header.Set("X-Tenant-ID", tt.tenantID)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+			var explanation RiskExplanation
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&explanation))
+			assert.Equal(t, RiskDecisionRejected, explanation.Decision)
+			assert.Equal(t, []string{"angola_foreign_currency"}, explanation.RuleIDs())
+			assert.True(t, explanation.Rules[0].Decisive)
+		})
+	}
+}
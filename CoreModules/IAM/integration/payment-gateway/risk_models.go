@@ -0,0 +1,137 @@
+package paymentgateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Decisões possíveis da avaliação de risco
+const (
+	RiskDecisionApproved = "approved"
+	RiskDecisionReview   = "review"
+	RiskDecisionRejected = "rejected"
+)
+
+// Limiares de score padrão que determinam a decisão de risco
+const (
+	DefaultRiskRejectThreshold = 0.8
+	DefaultRiskReviewThreshold = 0.5
+)
+
+// Erros do motor de risco
+var (
+	ErrTransactionRecordNotFound = errors.New("registo de transação não encontrado")
+	ErrRiskRuleSetInvalid        = errors.New("conjunto de regras de risco inválido")
+)
+
+// RiskEngineConfig contém configurações do motor de risco
+type RiskEngineConfig struct {
+	RejectThreshold float64 `json:"reject_threshold"` // Score a partir do qual a transação é rejeitada
+	ReviewThreshold float64 `json:"review_threshold"` // Score a partir do qual a transação exige verificação reforçada
+}
+
+// RiskRuleSet descreve um conjunto de regras de risco: ativação e score de cada regra do motor e
+// limiares de decisão. Usado para avaliar propostas de alteração em backtesting
+type RiskRuleSet struct {
+	Name            string                      `json:"name"`
+	RejectThreshold float64                     `json:"reject_threshold"`
+	ReviewThreshold float64                     `json:"review_threshold"`
+	Rules           map[string]RiskRuleOverride `json:"rules,omitempty"` // Regras omitidas mantêm o comportamento atual
+}
+
+// RiskRuleOverride altera uma regra do motor no conjunto proposto
+type RiskRuleOverride struct {
+	Enabled *bool    `json:"enabled,omitempty"`
+	Score   *float64 `json:"score,omitempty"` // Score atribuído quando a regra é acionada
+}
+
+// decision determina a decisão correspondente a um score segundo os limiares do conjunto
+func (s RiskRuleSet) decision(score float64) string {
+	switch {
+	case score >= s.RejectThreshold:
+		return RiskDecisionRejected
+	case score >= s.ReviewThreshold:
+		return RiskDecisionReview
+	default:
+		return RiskDecisionApproved
+	}
+}
+
+// validate verifica os limiares e se as regras alteradas existem no motor
+func (s RiskRuleSet) validate(knownRules map[string]bool) error {
+	if s.ReviewThreshold <= 0 || s.ReviewThreshold > s.RejectThreshold || s.RejectThreshold > 1 {
+		return fmt.Errorf("%w: revisão %.2f, rejeição %.2f (esperado 0 < revisão <= rejeição <= 1)",
+			ErrRiskRuleSetInvalid, s.ReviewThreshold, s.RejectThreshold)
+	}
+	for ruleID, override := range s.Rules {
+		if !knownRules[ruleID] {
+			return fmt.Errorf("%w: regra de risco desconhecida %s", ErrRiskRuleSetInvalid, ruleID)
+		}
+		if override.Score != nil && (*override.Score < 0 || *override.Score > 1) {
+			return fmt.Errorf("%w: score %.2f da regra %s", ErrRiskRuleSetInvalid, *override.Score, ruleID)
+		}
+	}
+	return nil
+}
+
+// RiskRule representa uma regra de risco
+type RiskRule struct {
+	ID          string
+	Name        string
+	Description string
+	Market      string // Código do mercado ou RegionGlobal
+	Severity    string
+	Remediation string // Orientação para a equipa de suporte quando a regra é acionada
+	Evaluate    func(req *PaymentRequest) (bool, float64, error)
+	Explain     func(req *PaymentRequest) []string // Condições observadas na transação (opcional)
+}
+
+// RiskExplanation descreve como o motor de risco chegou ao score de uma transação
+type RiskExplanation struct {
+	TransactionID string                `json:"transaction_id"`
+	TenantID      string                `json:"tenant_id"`
+	Market        string                `json:"market"`
+	RiskScore     float64               `json:"risk_score"`
+	Decision      string                `json:"decision"`
+	Rules         []RiskRuleExplanation `json:"rules"`
+	Sandbox       bool                  `json:"sandbox,omitempty"` // Pagamento de um comerciante em modo sandbox
+	EvaluatedAt   time.Time             `json:"evaluated_at"`
+}
+
+// RiskRuleExplanation detalha uma regra de risco acionada
+type RiskRuleExplanation struct {
+	RuleID            string   `json:"rule_id"`
+	Name              string   `json:"name"`
+	Severity          string   `json:"severity"`
+	Market            string   `json:"market"`
+	Contribution      float64  `json:"contribution"`
+	Decisive          bool     `json:"decisive"` // Regra que determinou o score final
+	MatchedConditions []string `json:"matched_conditions"`
+	Remediation       string   `json:"remediation,omitempty"`
+}
+
+// RuleIDs retorna os identificadores das regras acionadas
+func (e *RiskExplanation) RuleIDs() []string {
+	ids := make([]string, 0, len(e.Rules))
+	for _, rule := range e.Rules {
+		ids = append(ids, rule.RuleID)
+	}
+	return ids
+}
+
+// TransactionRecord é o registo persistido de uma transação avaliada, com a explicação de
+// risco; não inclui os dados do meio de pagamento
+type TransactionRecord struct {
+	TransactionID     string           `json:"transaction_id" db:"transaction_id"`
+	TenantID          string           `json:"tenant_id" db:"tenant_id"`
+	MerchantID        string           `json:"merchant_id" db:"merchant_id"`
+	UserID            string           `json:"user_id" db:"user_id"`
+	DeviceFingerprint string           `json:"device_fingerprint,omitempty" db:"device_fingerprint"`
+	PaymentMethod     string           `json:"payment_method" db:"payment_method"`
+	Amount            float64          `json:"amount" db:"amount"`
+	Currency          string           `json:"currency" db:"currency"`
+	RiskScore         float64          `json:"risk_score" db:"risk_score"`
+	RiskExplanation   *RiskExplanation `json:"risk_explanation" db:"-"`
+	RecordedAt        time.Time        `json:"recorded_at" db:"recorded_at"`
+}
@@ -0,0 +1,495 @@
+package paymentgateway
+
+import (
+	"fmt"
+)
+
+// highValueThresholds são os limiares de alto valor por moeda
+var highValueThresholds = map[string]float64{
+	"USD": 5000,
+	"EUR": 4500,
+	"AOA": 500000,
+	"BRL": 10000,
+	"MZN": 100000,
+}
+
+// defaultHighValueThreshold é o limiar de alto valor das moedas sem limiar próprio
+const defaultHighValueThreshold = 5000
+
+// scaAmountThreshold é o valor em EUR acima do qual a PSD2 exige autenticação forte
+const scaAmountThreshold = 30
+
+// angolaSanctionedCountries são os países sob sanções segundo a UIF Angola
+var angolaSanctionedCountries = map[string]bool{"KP": true, "IR": true, "SY": true, "CU": true}
+
+// validPIXKeyTypes são os tipos de chave PIX aceites pelo BACEN
+var validPIXKeyTypes = map[string]bool{"cpf": true, "cnpj": true, "email": true, "phone": true, "random": true}
+
+// defaultRiskRules retorna as regras básicas e as regras regulatórias de cada mercado
+func defaultRiskRules() []RiskRule {
+	rules := basicRiskRules()
+	rules = append(rules, angolaRiskRules()...)
+	rules = append(rules, brazilRiskRules()...)
+	rules = append(rules, euRiskRules()...)
+	rules = append(rules, usaRiskRules()...)
+	rules = append(rules, mozambiqueRiskRules()...)
+	return rules
+}
+
+// basicRiskRules retorna as regras aplicadas em todos os mercados
+func basicRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "high_value_transaction",
+			Name:        "Transação de Alto Valor",
+			Description: "Verifica se a transação excede um limiar de alto valor",
+			Market:      RegionGlobal,
+			Severity:    "medium",
+			Remediation: "Solicitar comprovativo de origem dos fundos ou aprovar manualmente após contacto com o cliente",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{fmt.Sprintf("valor %.2f %s acima do limiar de alto valor", req.Amount, req.Currency)}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				threshold, exists := highValueThresholds[req.Currency]
+				if !exists {
+					threshold = defaultHighValueThreshold
+				}
+				if req.Amount > threshold {
+					return true, 0.6, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "address_mismatch",
+			Name:        "Discrepância de Endereço",
+			Description: "Verifica se há discrepância entre endereço de cobrança e entrega",
+			Market:      RegionGlobal,
+			Severity:    "low",
+			Remediation: "Confirmar o endereço de entrega com o cliente antes de reprocessar",
+			Explain: func(req *PaymentRequest) []string {
+				if req.ShippingAddress == nil || req.BillingAddress == nil {
+					return nil
+				}
+				if req.ShippingAddress.Country != req.BillingAddress.Country {
+					return []string{fmt.Sprintf("país de cobrança %s difere do país de entrega %s",
+						req.BillingAddress.Country, req.ShippingAddress.Country)}
+				}
+				return []string{fmt.Sprintf("estado de cobrança %s difere do estado de entrega %s",
+					req.BillingAddress.State, req.ShippingAddress.State)}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				// Sem os dois endereços a regra não se aplica
+				if req.ShippingAddress == nil || req.BillingAddress == nil {
+					return false, 0, nil
+				}
+				if req.ShippingAddress.Country != req.BillingAddress.Country {
+					return true, 0.7, nil
+				}
+				if req.ShippingAddress.State != req.BillingAddress.State {
+					return true, 0.4, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "rapid_succession",
+			Name:        "Transações em Rápida Sucessão",
+			Description: "Verifica se há múltiplas transações do mesmo usuário em curto período",
+			Market:      RegionGlobal,
+			Severity:    "medium",
+			Remediation: "Verificar com o cliente se as transações recentes são legítimas",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{fmt.Sprintf("%d transações anteriores em curto período", len(req.PreviousTransactions))}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if len(req.PreviousTransactions) > 2 {
+					return true, 0.5, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "instalment_delinquency",
+			Name:        "Incumprimento de Parcelamento",
+			Description: "Verifica se o usuário tem parcelas em atraso ou planos de parcelamento em incumprimento",
+			Market:      RegionGlobal,
+			Severity:    "high",
+			Remediation: "Confirmar a regularização das parcelas em atraso antes de aprovar novas compras parceladas",
+			Explain: func(req *PaymentRequest) []string {
+				summary := req.InstalmentDelinquency
+				if summary == nil {
+					return nil
+				}
+				return []string{fmt.Sprintf("%d plano(s) em incumprimento e %d parcela(s) em atraso (%.2f)",
+					summary.DelinquentPlans, summary.OverdueInstalments, summary.OverdueAmount)}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				// Novas compras parceladas de um usuário em incumprimento são rejeitadas e os restantes pagamentos revistos
+				summary := req.InstalmentDelinquency
+				if summary == nil {
+					return false, 0, nil
+				}
+				instalmentPurchase := req.PaymentMethod == PaymentMethodInstalment
+				switch {
+				case summary.DelinquentPlans > 0 && instalmentPurchase:
+					return true, 0.85, nil
+				case summary.DelinquentPlans > 0:
+					return true, 0.6, nil
+				case summary.OverdueInstalments > 0 && instalmentPurchase:
+					return true, 0.5, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "confirmed_fraud_history",
+			Name:        "Histórico de Fraude Confirmada",
+			Description: "Verifica se o usuário ou o dispositivo têm transações com fraude confirmada",
+			Market:      RegionGlobal,
+			Severity:    "critical",
+			Remediation: "Confirmar a identidade do cliente e a posse do dispositivo antes de aprovar manualmente",
+			Explain: func(req *PaymentRequest) []string {
+				history := req.FraudHistory
+				if history == nil {
+					return nil
+				}
+				conditions := make([]string, 0, 2)
+				if history.UserConfirmedFraud > 0 {
+					conditions = append(conditions, fmt.Sprintf("usuário com %d fraude(s) confirmada(s)", history.UserConfirmedFraud))
+				}
+				if history.DeviceConfirmedFraud > 0 {
+					conditions = append(conditions, fmt.Sprintf("dispositivo com %d fraude(s) confirmada(s)", history.DeviceConfirmedFraud))
+				}
+				return conditions
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				history := req.FraudHistory
+				if history != nil && (history.UserConfirmedFraud > 0 || history.DeviceConfirmedFraud > 0) {
+					return true, 0.9, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "merchant_risk_profile",
+			Name:        "Perfil de Risco do Comerciante",
+			Description: "Verifica o nível de risco do comerciante ajustado pelas fraudes confirmadas",
+			Market:      RegionGlobal,
+			Severity:    "high",
+			Remediation: "Rever a taxa de fraude do comerciante com a equipa de risco antes de aprovar manualmente",
+			Explain: func(req *PaymentRequest) []string {
+				history := req.FraudHistory
+				if history == nil {
+					return nil
+				}
+				return []string{fmt.Sprintf("comerciante com nível %s (taxa de fraude %.2f%%)",
+					history.MerchantLevel, history.MerchantFraudRate*100)}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if history := req.FraudHistory; history != nil && history.MerchantRiskScore > 0 {
+					return true, history.MerchantRiskScore, nil
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// angolaRiskRules retorna as regras do controlo cambial do BNA e das sanções da UIF Angola
+func angolaRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "angola_foreign_currency",
+			Name:        "Transação em Moeda Estrangeira",
+			Description: "Verifica transações em moeda estrangeira conforme requisitos BNA",
+			Market:      RegionAngola,
+			Severity:    "high",
+			Remediation: "Anexar a autorização de câmbio do BNA (exchange_authorization) e reprocessar",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{fmt.Sprintf("moeda %s diferente de AOA", req.Currency), "autorização de câmbio ausente"}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.Currency != "AOA" {
+					if _, exists := req.Metadata["exchange_authorization"]; !exists {
+						return true, 0.8, nil
+					}
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "angola_sanctioned_countries",
+			Name:        "Transação para País sob Sanção",
+			Description: "Verifica transações para países sob sanções conforme BNA/UIF",
+			Market:      RegionAngola,
+			Severity:    "high",
+			Remediation: "Encaminhar para a equipa de compliance (UIF Angola); a transação não pode ser aprovada pelo suporte",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{"país de destino consta da lista de sanções BNA/UIF"}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if angolaSanctionedCountries[destinationCountry(req)] {
+					return true, 0.9, nil
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// brazilRiskRules retorna as regras de prevenção à lavagem do COAF e de validação PIX do BACEN
+func brazilRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "brazil_coaf_suspicious",
+			Name:        "Transação Suspeita COAF",
+			Description: "Verifica padrões de transação suspeita conforme diretrizes COAF",
+			Market:      RegionBrazil,
+			Severity:    "high",
+			Remediation: "Encaminhar para análise de PLD/COAF antes de qualquer reprocessamento",
+			Explain: func(req *PaymentRequest) []string {
+				var conditions []string
+				if len(req.PreviousTransactions) > 3 && req.Amount < 5000 {
+					conditions = append(conditions, fmt.Sprintf("possível fracionamento: %d transações anteriores abaixo de 5000",
+						len(req.PreviousTransactions)))
+				}
+				if isPEP, _ := req.Metadata["is_pep"].(bool); isPEP {
+					conditions = append(conditions, "cliente identificado como Pessoa Politicamente Exposta")
+				}
+				return conditions
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				// Transações fracionadas em múltiplas transações pequenas
+				if len(req.PreviousTransactions) > 3 && req.Amount < 5000 {
+					return true, 0.7, nil
+				}
+				// Pessoas Politicamente Expostas
+				if isPEP, _ := req.Metadata["is_pep"].(bool); isPEP {
+					return true, 0.8, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "brazil_pix_validation",
+			Name:        "Validação PIX",
+			Description: "Valida transações PIX conforme requisitos BACEN",
+			Market:      RegionBrazil,
+			Severity:    "medium",
+			Remediation: "Validar a chave PIX do recebedor ou reagendar o pagamento para horário diurno",
+			Explain: func(req *PaymentRequest) []string {
+				var conditions []string
+				if req.Amount > 100000 && isNightTime(req) {
+					conditions = append(conditions, "PIX de alto valor em período noturno")
+				}
+				if pixKeyType, _ := req.PaymentDetails["pix_key_type"].(string); pixKeyType != "" {
+					conditions = append(conditions, fmt.Sprintf("tipo de chave PIX %q", pixKeyType))
+				} else {
+					conditions = append(conditions, "tipo de chave PIX ausente")
+				}
+				return conditions
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.PaymentMethod != PaymentMethodPIX {
+					return false, 0, nil
+				}
+				// Limite do PIX noturno
+				if req.Amount > 100000 && isNightTime(req) {
+					return true, 0.6, nil
+				}
+				if pixKeyType, _ := req.PaymentDetails["pix_key_type"].(string); !validPIXKeyTypes[pixKeyType] {
+					return true, 0.5, nil
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// euRiskRules retorna as regras de autenticação forte da PSD2 e de validação SEPA
+func euRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "eu_sca_compliance",
+			Name:        "Conformidade SCA",
+			Description: "Verifica conformidade com Strong Customer Authentication (PSD2)",
+			Market:      RegionEU,
+			Severity:    "high",
+			Remediation: "Solicitar autenticação forte (MFA de nível alto) e 3-D Secure ao cliente, ou aplicar isenção SCA válida",
+			Explain: func(req *PaymentRequest) []string {
+				conditions := []string{fmt.Sprintf("valor %.2f EUR acima do limite de isenção SCA", req.Amount)}
+				if req.SCAExemption != nil {
+					conditions = []string{req.SCAExemption.Reason}
+				}
+				if req.MFALevel != "high" {
+					conditions = append(conditions, fmt.Sprintf("nível MFA %q inferior ao exigido", req.MFALevel))
+				}
+				if req.PaymentMethod == PaymentMethodCard && req.ThreeDSData["version"] == nil {
+					conditions = append(conditions, "dados 3-D Secure ausentes")
+				}
+				return conditions
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if !scaRequired(req) {
+					return false, 0, nil
+				}
+				if req.MFALevel != "high" {
+					return true, 0.8, nil
+				}
+				if req.PaymentMethod == PaymentMethodCard && req.ThreeDSData["version"] == nil {
+					return true, 0.9, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "eu_sepa_validation",
+			Name:        "Validação SEPA",
+			Description: "Valida transferências SEPA conforme regulamentações",
+			Market:      RegionEU,
+			Severity:    "medium",
+			Remediation: "Corrigir o IBAN e o BIC do beneficiário e reenviar a transferência",
+			Explain: func(req *PaymentRequest) []string {
+				var conditions []string
+				if iban, _ := req.PaymentDetails["iban"].(string); len(iban) < 15 {
+					conditions = append(conditions, "IBAN ausente ou inválido")
+				}
+				if _, exists := req.PaymentDetails["bic"]; !exists {
+					conditions = append(conditions, "BIC/SWIFT ausente")
+				}
+				return conditions
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.PaymentMethod != PaymentMethodSEPA {
+					return false, 0, nil
+				}
+				if iban, _ := req.PaymentDetails["iban"].(string); len(iban) < 15 {
+					return true, 0.6, nil
+				}
+				if _, exists := req.PaymentDetails["bic"]; !exists {
+					return true, 0.5, nil
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// usaRiskRules retorna as regras de sanções da OFAC e de reporte do Bank Secrecy Act
+func usaRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "usa_ofac_compliance",
+			Name:        "Conformidade OFAC",
+			Description: "Verifica conformidade com lista de sanções OFAC",
+			Market:      RegionUSA,
+			Severity:    "critical",
+			Remediation: "Encaminhar para a equipa de compliance (OFAC); a transação não pode ser aprovada pelo suporte",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{"correspondência na lista de sanções OFAC"}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if ofacMatch, _ := req.Metadata["ofac_match"].(bool); ofacMatch {
+					return true, 1.0, nil
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "usa_bsa_compliance",
+			Name:        "Conformidade BSA",
+			Description: "Verifica conformidade com Bank Secrecy Act",
+			Market:      RegionUSA,
+			Severity:    "high",
+			Remediation: "Registar o Currency Transaction Report (ctr_filed) ou encaminhar para análise de estruturação",
+			Explain: func(req *PaymentRequest) []string {
+				if req.Amount > 10000 {
+					return []string{fmt.Sprintf("valor %.2f USD acima do limite CTR sem relatório registado", req.Amount)}
+				}
+				return []string{fmt.Sprintf("possível estruturação: %d transações anteriores abaixo do limite CTR",
+					len(req.PreviousTransactions))}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.Currency != "USD" {
+					return false, 0, nil
+				}
+				// Transações acima de 10.000 USD exigem relatório CTR
+				if req.Amount > 10000 {
+					if _, exists := req.Metadata["ctr_filed"].(bool); !exists {
+						return true, 0.8, nil
+					}
+				}
+				// Estruturação: várias transações abaixo do limite de reporte que, somadas, o ultrapassam;
+				// as transações anteriores contam pelo valor mínimo da faixa vigiada
+				if req.Amount > 3000 && req.Amount < 10000 && len(req.PreviousTransactions) > 2 {
+					if req.Amount+float64(len(req.PreviousTransactions))*3000 > 10000 {
+						return true, 0.7, nil
+					}
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// mozambiqueRiskRules retorna as regras cambiais do Banco de Moçambique e de reporte ao GIFiM
+func mozambiqueRiskRules() []RiskRule {
+	return []RiskRule{
+		{
+			ID:          "mozambique_foreign_currency",
+			Name:        "Transação em Moeda Estrangeira",
+			Description: "Verifica transações em moeda estrangeira conforme Banco de Moçambique",
+			Market:      RegionMozambique,
+			Severity:    "high",
+			Remediation: "Anexar a autorização de câmbio do Banco de Moçambique (exchange_authorization) e reprocessar",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{fmt.Sprintf("moeda %s diferente de MZN", req.Currency), "autorização de câmbio ausente"}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.Currency != "MZN" {
+					if _, exists := req.Metadata["exchange_authorization"]; !exists {
+						return true, 0.8, nil
+					}
+				}
+				return false, 0, nil
+			},
+		},
+		{
+			ID:          "mozambique_high_value",
+			Name:        "Transação de Alto Valor",
+			Description: "Verifica transações de alto valor conforme GIFiM",
+			Market:      RegionMozambique,
+			Severity:    "medium",
+			Remediation: "Registar o relatório GIFiM (gifim_report) e reprocessar",
+			Explain: func(req *PaymentRequest) []string {
+				return []string{fmt.Sprintf("valor %.2f MZN acima do limite de reporte GIFiM", req.Amount)}
+			},
+			Evaluate: func(req *PaymentRequest) (bool, float64, error) {
+				if req.Currency == "MZN" && req.Amount > 500000 {
+					if _, exists := req.Metadata["gifim_report"].(bool); !exists {
+						return true, 0.7, nil
+					}
+				}
+				return false, 0, nil
+			},
+		},
+	}
+}
+
+// destinationCountry retorna o país de entrega ou, na sua falta, o país de cobrança
+func destinationCountry(req *PaymentRequest) string {
+	if req.ShippingAddress != nil {
+		return req.ShippingAddress.Country
+	}
+	if req.BillingAddress != nil {
+		return req.BillingAddress.Country
+	}
+	return ""
+}
+
+// isNightTime indica se a transação foi criada no período noturno (20h-6h)
+func isNightTime(req *PaymentRequest) bool {
+	hour := req.Timestamp.Hour()
+	return hour >= 20 || hour < 6
+}
@@ -0,0 +1,64 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SandboxHandler expõe às equipas de suporte o modo sandbox dos comerciantes
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type SandboxHandler struct {
+	service *SandboxService
+}
+
+// NewSandboxHandler cria uma nova instância do SandboxHandler
+func NewSandboxHandler(service *SandboxService) *SandboxHandler {
+	return &SandboxHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *SandboxHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/merchants/{merchantId}/sandbox", h.GetMerchantSandbox).Methods(http.MethodGet)
+	router.HandleFunc("/support/merchants/{merchantId}/sandbox", h.SetMerchantSandbox).Methods(http.MethodPut)
+}
+
+// RegisterWebhookRoutes registra o webhook do simulador de PSP externo do sandbox, assinado com o
+// segredo do PSP do sandbox. Fica fora do SupportAuth, como /psp/webhooks
+func (h *SandboxHandler) RegisterWebhookRoutes(router *mux.Router) {
+	router.HandleFunc("/psp/sandbox/webhooks", NewPSPHandler(h.service.PSP()).Webhook).Methods(http.MethodPost)
+}
+
+// GetMerchantSandbox retorna o modo sandbox do comerciante do tenant
+func (h *SandboxHandler) GetMerchantSandbox(w http.ResponseWriter, r *http.Request) {
+	sandbox, err := h.service.GetMerchantSandbox(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao recuperar modo sandbox do comerciante")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sandbox)
+}
+
+// SetMerchantSandbox ativa ou desativa o modo sandbox do comerciante com {"sandbox": true}
+func (h *SandboxHandler) SetMerchantSandbox(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Sandbox *bool `json:"sandbox"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil || body.Sandbox == nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "sandbox obrigatório")
+		return
+	}
+
+	tenantID := r.Header.Get("X-Tenant-ID")
+	merchantID := mux.Vars(r)["merchantId"]
+	sandbox, err := h.service.SetMerchantSandbox(r.Context(), tenantID, merchantID, *body.Sandbox, supportOperator(r))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao alterar modo sandbox do comerciante")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sandbox)
+}
@@ -0,0 +1,45 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Destino dos webhooks do simulador no processo, entregues diretamente ao PSP do sandbox
+	sandboxInProcessNotificationURL = "http://sandbox.innovabiz.internal/psp/sandbox/webhooks"
+
+	// Endpoint fictício do simulador no processo; os pedidos nunca saem do gateway
+	sandboxInProcessEndpoint = "http://psp-simulator.sandbox.innovabiz.internal"
+
+	// Prefixo das métricas dos pagamentos em sandbox, que substitui payment_gateway_
+	sandboxMetricPrefix = "payment_gateway_sandbox_"
+)
+
+// Erros do sandbox por comerciante
+var (
+	ErrMerchantSandboxNotFound = errors.New("modo sandbox do comerciante não configurado")
+)
+
+// MerchantSandbox indica se um comerciante está em modo sandbox. Os pagamentos dos comerciantes em
+// sandbox passam pelas mesmas verificações de limites, risco, compliance e verificação cruzada, mas
+// são autorizados no simulador de PSP e não movimentam fundos
+type MerchantSandbox struct {
+	TenantID   string    `json:"tenant_id" db:"tenant_id"`
+	MerchantID string    `json:"merchant_id" db:"merchant_id"`
+	Enabled    bool      `json:"sandbox" db:"enabled"`
+	UpdatedBy  string    `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SandboxConfig define o PSP dos pagamentos em sandbox
+type SandboxConfig struct {
+	// PSP do sandbox; sem Endpoint é usado um simulador no processo, cujos pedidos e webhooks não
+	// passam pela rede. Com Endpoint (simulador externo), os webhooks são recebidos em
+	// /psp/sandbox/webhooks e assinados com PSP.WebhookSecret
+	PSP PSPConfig `json:"psp"`
+
+	// Configuração do simulador no processo; a chave de API, o segredo e o destino dos webhooks
+	// são definidos pelo sandbox
+	Simulator PSPSimulatorConfig `json:"simulator"`
+}
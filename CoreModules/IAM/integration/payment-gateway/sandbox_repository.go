@@ -0,0 +1,58 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresSandboxMerchantStore implementa SandboxMerchantStore para PostgreSQL
+type PostgresSandboxMerchantStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresSandboxMerchantStore cria uma nova instância de PostgresSandboxMerchantStore
+func NewPostgresSandboxMerchantStore(db *sqlx.DB) *PostgresSandboxMerchantStore {
+	return &PostgresSandboxMerchantStore{db: db}
+}
+
+// SaveMerchantSandbox grava o modo sandbox do comerciante, substituindo o anterior
+func (r *PostgresSandboxMerchantStore) SaveMerchantSandbox(ctx context.Context, sandbox *MerchantSandbox) error {
+	query := `
+		INSERT INTO payment_gateway.merchant_sandbox (
+			tenant_id, merchant_id, enabled, updated_by, updated_at
+		) VALUES (
+			:tenant_id, :merchant_id, :enabled, :updated_by, :updated_at
+		)
+		ON CONFLICT (tenant_id, merchant_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, sandbox); err != nil {
+		return fmt.Errorf("falha ao gravar modo sandbox do comerciante: %w", err)
+	}
+
+	return nil
+}
+
+// GetMerchantSandbox recupera o modo sandbox do comerciante do tenant
+func (r *PostgresSandboxMerchantStore) GetMerchantSandbox(ctx context.Context, tenantID, merchantID string) (*MerchantSandbox, error) {
+	var sandbox MerchantSandbox
+	query := `
+		SELECT tenant_id, merchant_id, enabled, updated_by, updated_at
+		FROM payment_gateway.merchant_sandbox
+		WHERE tenant_id = $1 AND merchant_id = $2
+	`
+	if err := r.db.GetContext(ctx, &sandbox, query, tenantID, merchantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMerchantSandboxNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar modo sandbox do comerciante: %w", err)
+	}
+	return &sandbox, nil
+}
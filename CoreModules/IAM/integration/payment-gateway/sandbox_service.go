@@ -0,0 +1,163 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// SandboxService gere o modo sandbox dos comerciantes e o PSP dos seus pagamentos. Os pagamentos em
+// sandbox passam pelas mesmas verificações dos reais, mas são autorizados no simulador de PSP, sem
+// adquirentes, tokens de rede, remessas, QR codes nem planos de parcelas. As autorizações ficam em
+// armazenamento próprio, as métricas usam o prefixo payment_gateway_sandbox_ e as respostas e os
+// webhooks aos comerciantes indicam sandbox=true
+type SandboxService struct {
+	store     SandboxMerchantStore
+	psp       *PSPService
+	simulator *PSPSimulator // Simulador no processo; nil com simulador externo
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewSandboxService cria o serviço de sandbox. As autorizações do sandbox são gravadas em
+// authorizations, que não deve ser partilhado com o PSP real
+func NewSandboxService(config SandboxConfig, store SandboxMerchantStore, authorizations PSPAuthorizationStore) (*SandboxService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-sandbox",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	service := &SandboxService{
+		store:           store,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}
+
+	config.PSP.Sandbox = true
+	if config.PSP.Endpoint != "" {
+		if service.psp, err = NewPSPService(config.PSP, authorizations, nil); err != nil {
+			return nil, err
+		}
+		service.logger.Info("Serviço de sandbox inicializado", "psp_endpoint", config.PSP.Endpoint)
+		return service, nil
+	}
+
+	// Segredo efémero: os webhooks do simulador no processo nunca saem do gateway
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("falha ao gerar segredo dos webhooks do sandbox: %w", err)
+	}
+	config.PSP.Endpoint = sandboxInProcessEndpoint
+	config.PSP.APIKey = ""
+	config.PSP.NotificationURL = sandboxInProcessNotificationURL
+	config.PSP.WebhookSecret = hex.EncodeToString(secret)
+
+	config.Simulator.APIKey = ""
+	config.Simulator.WebhookSecret = config.PSP.WebhookSecret
+	config.Simulator.WebhookURL = ""
+	if service.simulator, err = NewPSPSimulator(config.Simulator); err != nil {
+		return nil, err
+	}
+
+	client := NewHTTPPSPClient(config.PSP)
+	client.httpClient.Transport = inProcessTransport{handler: service.simulator}
+	if service.psp, err = NewPSPService(config.PSP, authorizations, client); err != nil {
+		return nil, err
+	}
+	service.simulator.client.Transport = inProcessTransport{handler: http.HandlerFunc(NewPSPHandler(service.psp).Webhook)}
+
+	service.logger.Info("Serviço de sandbox inicializado com simulador de PSP no processo")
+	return service, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes do desfecho das autorizações
+// pendentes do sandbox
+func (s *SandboxService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.psp.SetWebhookDeliveryService(webhooks)
+}
+
+// PSP retorna o PSP dos pagamentos em sandbox
+func (s *SandboxService) PSP() *PSPService {
+	return s.psp
+}
+
+// IsSandbox indica se o comerciante do tenant está em modo sandbox. Um erro do armazenamento é
+// retornado ao chamador: tratar o comerciante como real moveria fundos num pagamento de teste
+func (s *SandboxService) IsSandbox(ctx context.Context, tenantID, merchantID string) (bool, error) {
+	sandbox, err := s.GetMerchantSandbox(ctx, tenantID, merchantID)
+	if err != nil {
+		return false, err
+	}
+	return sandbox.Enabled, nil
+}
+
+// GetMerchantSandbox retorna o modo sandbox do comerciante; os comerciantes nunca configurados
+// não estão em sandbox
+func (s *SandboxService) GetMerchantSandbox(ctx context.Context, tenantID, merchantID string) (*MerchantSandbox, error) {
+	sandbox, err := s.store.GetMerchantSandbox(ctx, tenantID, merchantID)
+	if errors.Is(err, ErrMerchantSandboxNotFound) {
+		return &MerchantSandbox{TenantID: tenantID, MerchantID: merchantID}, nil
+	}
+	return sandbox, err
+}
+
+// SetMerchantSandbox ativa ou desativa o modo sandbox do comerciante. Os pagamentos já iniciados
+// terminam no ambiente em que começaram
+func (s *SandboxService) SetMerchantSandbox(ctx context.Context, tenantID, merchantID string, enabled bool, operator string) (*MerchantSandbox, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "SandboxService.SetMerchantSandbox")
+	defer span.End()
+
+	sandbox := &MerchantSandbox{
+		TenantID:   tenantID,
+		MerchantID: merchantID,
+		Enabled:    enabled,
+		UpdatedBy:  operator,
+		UpdatedAt:  s.now().UTC(),
+	}
+	if err := s.store.SaveMerchantSandbox(ctx, sandbox); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Modo sandbox do comerciante alterado",
+		"tenant_id", tenantID,
+		"merchant_id", merchantID,
+		"sandbox", enabled,
+		"operator", operator)
+	return sandbox, nil
+}
+
+// Wait aguarda os webhooks pendentes do simulador no processo
+func (s *SandboxService) Wait() {
+	if s.simulator != nil {
+		s.simulator.Wait()
+	}
+}
+
+// paymentMetric retorna o nome da métrica do pagamento; os pagamentos em sandbox são contabilizados
+// em payment_gateway_sandbox_* para não se misturarem com os reais (ex.: payment_processing_time_ms
+// passa a payment_gateway_sandbox_processing_time_ms)
+func paymentMetric(req *PaymentRequest, name string) string {
+	if !req.Sandbox {
+		return name
+	}
+	name = strings.TrimPrefix(name, "payment_gateway_")
+	return sandboxMetricPrefix + strings.TrimPrefix(name, "payment_")
+}
@@ -0,0 +1,221 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSandboxMerchantStore falha todas as consultas do modo sandbox
+type failingSandboxMerchantStore struct{}
+
+func (failingSandboxMerchantStore) SaveMerchantSandbox(ctx context.Context, sandbox *MerchantSandbox) error {
+	return errors.New("base de dados indisponível")
+}
+
+func (failingSandboxMerchantStore) GetMerchantSandbox(ctx context.Context, tenantID, merchantID string) (*MerchantSandbox, error) {
+	return nil, errors.New("base de dados indisponível")
+}
+
+// newTestSandboxService cria o sandbox com o simulador no processo e o merchant-1 em sandbox
+func newTestSandboxService(t *testing.T) (*SandboxService, *InMemoryPSPAuthorizationStore) {
+	t.Helper()
+
+	authorizations := NewInMemoryPSPAuthorizationStore()
+	service, err := NewSandboxService(SandboxConfig{
+		Simulator: PSPSimulatorConfig{WebhookDelay: 10 * time.Millisecond},
+	}, NewInMemorySandboxMerchantStore(), authorizations)
+	require.NoError(t, err)
+	t.Cleanup(service.Wait)
+
+	_, err = service.SetMerchantSandbox(context.Background(), "tenant-1", "merchant-1", true, "operador-1")
+	require.NoError(t, err)
+	return service, authorizations
+}
+
+func TestProcessPaymentSandboxUsesSimulator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("aprovado no simulador sem chegar ao PSP real", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		psp, realAuthorizations := newTestPSPService(t, PSPConfig{Endpoint: "http://psp.invalid"})
+		connector.SetPSPService(psp)
+		sandbox, sandboxAuthorizations := newTestSandboxService(t)
+		connector.SetSandboxService(sandbox)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("sandbox", "4111111111111111", 120))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.Equal(t, true, response.Metadata["sandbox"])
+
+		_, err = sandboxAuthorizations.GetPSPAuthorization(ctx, response.AuthorizationID)
+		assert.NoError(t, err)
+		_, err = realAuthorizations.GetPSPAuthorization(ctx, response.AuthorizationID)
+		assert.ErrorIs(t, err, ErrPSPAuthorizationNotFound)
+	})
+
+	t.Run("cartão mágico recusado", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		sandbox, _ := newTestSandboxService(t)
+		connector.SetSandboxService(sandbox)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("sandbox-declined", PSPSimulatorCardDoNotHonor, 120))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusDenied, response.Status)
+		assert.Equal(t, "psp_recusado", response.StatusCode)
+		assert.Equal(t, true, response.Metadata["sandbox"])
+	})
+
+	t.Run("comerciante fora do sandbox usa o PSP real", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		psp, _ := newTestPSPService(t, PSPConfig{Endpoint: "http://psp.invalid", Timeout: 200 * time.Millisecond})
+		connector.SetPSPService(psp)
+		sandbox, _ := newTestSandboxService(t)
+		_, err := sandbox.SetMerchantSandbox(ctx, "tenant-1", "merchant-1", false, "operador-1")
+		require.NoError(t, err)
+		connector.SetSandboxService(sandbox)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("real", "4111111111111111", 120))
+		require.NoError(t, err)
+		assert.Equal(t, "psp_indisponivel", response.StatusCode)
+		assert.NotContains(t, response.Metadata, "sandbox")
+	})
+
+	t.Run("sem resposta do sandbox o pagamento não prossegue", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		sandbox, err := NewSandboxService(SandboxConfig{}, failingSandboxMerchantStore{}, NewInMemoryPSPAuthorizationStore())
+		require.NoError(t, err)
+		connector.SetSandboxService(sandbox)
+
+		response, err := connector.ProcessPayment(ctx, testPSPRequest("sandbox-error", "4111111111111111", 120))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusError, response.Status)
+		assert.Equal(t, "sandbox_erro", response.StatusCode)
+	})
+}
+
+func TestProcessPaymentSandboxPendingCompletedInProcess(t *testing.T) {
+	ctx := context.Background()
+	connector, _ := newTestConnector(t)
+	sandbox, authorizations := newTestSandboxService(t)
+	webhooks, deliveries, _ := newTestWebhookDeliveryService(t,
+		WebhookDeliveryConfig{Endpoints: map[string]string{"merchant-1": "https://merchant.example/webhooks"}}, nil)
+	sandbox.SetWebhookDeliveryService(webhooks)
+	connector.SetSandboxService(sandbox)
+	connector.SetWebhookDeliveryService(webhooks)
+
+	response, err := connector.ProcessPayment(ctx, testPSPRequest("sandbox-pending", "4111111111111111", 60.93))
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusPending, response.Status)
+
+	sandbox.Wait()
+	authorization, err := authorizations.GetPSPAuthorization(ctx, response.AuthorizationID)
+	require.NoError(t, err)
+	assert.Equal(t, PSPStatusApproved, authorization.Status)
+
+	list, err := deliveries.ListDeliveries(ctx, WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, delivery := range list {
+		assert.True(t, delivery.Event.Sandbox, delivery.Event.EventType)
+	}
+}
+
+func TestProcessPaymentSandboxIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("parcelamento sem captura da primeira parcela", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		client := &fakeInstalmentCaptureClient{}
+		instalments, _, _ := newTestInstalmentService(t, client)
+		connector.SetInstalmentService(instalments)
+		sandbox, _ := newTestSandboxService(t)
+		connector.SetSandboxService(sandbox)
+
+		response, err := connector.ProcessPayment(ctx, testInstalmentRequest(RegionBrazil, "BRL", 1000, 3, 0.02))
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.NotContains(t, response.Metadata, "instalment_plan_id")
+		assert.Empty(t, client.captured())
+	})
+
+	t.Run("explicação de risco identificada como sandbox", func(t *testing.T) {
+		connector, _ := newTestConnector(t)
+		records := NewInMemoryTransactionRecordStore()
+		connector.SetRiskEngine(newTestRiskEngine(t, records))
+		sandbox, _ := newTestSandboxService(t)
+		connector.SetSandboxService(sandbox)
+
+		_, err := connector.ProcessPayment(ctx, testRiskRequest(RegionUSA, "USD", 50))
+		require.NoError(t, err)
+		record, err := records.GetTransactionRecord(ctx, "tenant-1", "tx-1")
+		require.NoError(t, err)
+		assert.True(t, record.RiskExplanation.Sandbox)
+	})
+}
+
+func TestPaymentMetric(t *testing.T) {
+	tests := []struct {
+		name    string
+		sandbox bool
+		metric  string
+		want    string
+	}{
+		{"real", false, "payment_gateway_requests_total", "payment_gateway_requests_total"},
+		{"sandbox com prefixo do gateway", true, "payment_gateway_requests_total", "payment_gateway_sandbox_requests_total"},
+		{"sandbox com prefixo de pagamento", true, "payment_processing_time_ms", "payment_gateway_sandbox_processing_time_ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, paymentMetric(&PaymentRequest{Sandbox: tt.sandbox}, tt.metric))
+		})
+	}
+}
+
+func TestSandboxHandler(t *testing.T) {
+	sandbox, err := NewSandboxService(SandboxConfig{}, NewInMemorySandboxMerchantStore(), NewInMemoryPSPAuthorizationStore())
+	require.NoError(t, err)
+	router := mux.NewRouter()
+	NewSandboxHandler(sandbox).RegisterRoutes(router)
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/support/merchants/merchant-1/sandbox", bytes.NewBufferString(body))
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) MerchantSandbox {
+		var sandbox MerchantSandbox
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&sandbox))
+		return sandbox
+	}
+
+	rec := serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, decode(rec).Enabled)
+
+	rec = serve(http.MethodPut, `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve(http.MethodPut, `{"sandbox": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, decode(rec).Enabled)
+
+	rec = serve(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, decode(rec).Enabled)
+
+	enabled, err := sandbox.IsSandbox(context.Background(), "tenant-2", "merchant-1")
+	require.NoError(t, err)
+	assert.False(t, enabled, "o modo sandbox é do comerciante do tenant")
+}
@@ -0,0 +1,50 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// SandboxMerchantStore define a persistência do modo sandbox dos comerciantes
+type SandboxMerchantStore interface {
+	// SaveMerchantSandbox grava o modo sandbox do comerciante, substituindo o anterior
+	SaveMerchantSandbox(ctx context.Context, sandbox *MerchantSandbox) error
+
+	// GetMerchantSandbox recupera o modo sandbox do comerciante do tenant; retorna
+	// ErrMerchantSandboxNotFound quando nunca foi configurado
+	GetMerchantSandbox(ctx context.Context, tenantID, merchantID string) (*MerchantSandbox, error)
+}
+
+// InMemorySandboxMerchantStore armazena o modo sandbox dos comerciantes em memória
+type InMemorySandboxMerchantStore struct {
+	merchants map[string]*MerchantSandbox // Por tenant e comerciante
+	mutex     sync.RWMutex
+}
+
+// NewInMemorySandboxMerchantStore cria um novo armazenamento em memória
+func NewInMemorySandboxMerchantStore() *InMemorySandboxMerchantStore {
+	return &InMemorySandboxMerchantStore{merchants: make(map[string]*MerchantSandbox)}
+}
+
+// SaveMerchantSandbox grava uma cópia do modo sandbox do comerciante
+func (s *InMemorySandboxMerchantStore) SaveMerchantSandbox(ctx context.Context, sandbox *MerchantSandbox) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *sandbox
+	s.merchants[sandbox.TenantID+"/"+sandbox.MerchantID] = &copied
+	return nil
+}
+
+// GetMerchantSandbox retorna uma cópia do modo sandbox do comerciante
+func (s *InMemorySandboxMerchantStore) GetMerchantSandbox(ctx context.Context, tenantID, merchantID string) (*MerchantSandbox, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sandbox, ok := s.merchants[tenantID+"/"+merchantID]
+	if !ok {
+		return nil, ErrMerchantSandboxNotFound
+	}
+	copied := *sandbox
+	return &copied, nil
+}
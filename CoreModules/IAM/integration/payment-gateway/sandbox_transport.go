@@ -0,0 +1,62 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// inProcessTransport entrega os pedidos HTTP a um handler do próprio processo, usado entre o
+// PSP do sandbox e o simulador no processo
+type inProcessTransport struct {
+	handler http.Handler
+}
+
+// inProcessResponse guarda a resposta escrita pelo handler
+type inProcessResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *inProcessResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *inProcessResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *inProcessResponse) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
+
+// RoundTrip executa o handler e converte a resposta. Um pedido cancelado ou com o prazo
+// esgotado retorna o erro do contexto, como numa ligação de rede
+func (t inProcessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	recorded := &inProcessResponse{header: make(http.Header)}
+	t.handler.ServeHTTP(recorded, req)
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	recorded.WriteHeader(http.StatusOK)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.status, http.StatusText(recorded.status)),
+		StatusCode:    recorded.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.header,
+		Body:          io.NopCloser(&recorded.body),
+		ContentLength: int64(recorded.body.Len()),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// SCAExemptionHandler expõe às equipas de suporte o reporte de isenções SCA ao adquirente, as taxas
+// de fraude por instrumento, o reporte de fraude e as listas de beneficiários de confiança
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type SCAExemptionHandler struct {
+	service *SCAExemptionService
+}
+
+// NewSCAExemptionHandler cria uma nova instância do SCAExemptionHandler
+func NewSCAExemptionHandler(service *SCAExemptionService) *SCAExemptionHandler {
+	return &SCAExemptionHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *SCAExemptionHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/sca/exemptions", h.ListExemptions).Methods(http.MethodGet)
+	router.HandleFunc("/support/sca/fraud-rates", h.FraudRates).Methods(http.MethodGet)
+	router.HandleFunc("/support/sca/fraud-reports", h.ReportFraud).Methods(http.MethodPost)
+	router.HandleFunc("/support/sca/trusted-beneficiaries/{userId}", h.ListTrustedBeneficiaries).Methods(http.MethodGet)
+	router.HandleFunc("/support/sca/trusted-beneficiaries/{userId}", h.RemoveTrustedBeneficiary).
+		Methods(http.MethodDelete).Queries("key", "{key}")
+}
+
+// ListExemptions lista as decisões do tenant filtradas por merchant_id e pelo intervalo [from, to)
+// em RFC 3339; com format=csv responde no formato do reporte ao adquirente
+func (h *SCAExemptionHandler) ListExemptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := SCADecisionFilter{
+		TenantID:   r.Header.Get("X-Tenant-ID"),
+		MerchantID: query.Get("merchant_id"),
+	}
+	for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := query.Get(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro "+param+" inválido")
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	decisions, err := h.service.Decisions(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar decisões de isenção SCA")
+		return
+	}
+
+	if query.Get("format") != "csv" {
+		respondWithJSON(w, http.StatusOK, decisions)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	if err := WriteSCAExemptionReportCSV(w, decisions); err != nil {
+		h.service.logger.ErrorWithContext(r.Context(), "Falha ao gerar reporte de isenções SCA", "error", err.Error())
+	}
+}
+
+// FraudRates retorna a taxa de fraude de cada instrumento do tenant no período móvel
+func (h *SCAExemptionHandler) FraudRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.FraudRates(r.Context(), r.Header.Get("X-Tenant-ID"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao calcular taxas de fraude")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rates)
+}
+
+// ReportFraud marca um pagamento concluído como fraudulento, alterando a taxa usada na isenção TRA
+func (h *SCAExemptionHandler) ReportFraud(w http.ResponseWriter, r *http.Request) {
+	var report struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil || report.TransactionID == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "transaction_id obrigatório")
+		return
+	}
+
+	tenantID := r.Header.Get("X-Tenant-ID")
+	decision, err := h.service.ReportFraud(r.Context(), tenantID, report.TransactionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSCADecisionNotFound):
+			respondWithError(w, http.StatusNotFound, "not_found", "Decisão de isenção SCA não encontrada")
+		case errors.Is(err, ErrSCATransactionNotCompleted):
+			respondWithError(w, http.StatusConflict, "transaction_not_completed", "Transação não concluída")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao reportar fraude")
+		}
+		return
+	}
+
+	// Registar o reporte para auditoria das ações das equipas de suporte
+	h.service.logger.InfoWithContext(r.Context(), "Fraude reportada pelo suporte",
+		"tenant_id", tenantID,
+		"transaction_id", decision.TransactionID,
+		"instrument", decision.Instrument,
+		"exemption", decision.Exemption,
+		"operator", supportOperator(r))
+
+	respondWithJSON(w, http.StatusOK, decision)
+}
+
+// ListTrustedBeneficiaries lista a lista de confiança do pagador
+func (h *SCAExemptionHandler) ListTrustedBeneficiaries(w http.ResponseWriter, r *http.Request) {
+	beneficiaries, err := h.service.TrustedBeneficiaries(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["userId"])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar beneficiários de confiança")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, beneficiaries)
+}
+
+// RemoveTrustedBeneficiary retira o beneficiário indicado no parâmetro key da lista de confiança
+func (h *SCAExemptionHandler) RemoveTrustedBeneficiary(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get("X-Tenant-ID")
+	vars := mux.Vars(r)
+
+	if err := h.service.RemoveTrustedBeneficiary(r.Context(), tenantID, vars["userId"], vars["key"]); err != nil {
+		if errors.Is(err, ErrSCATrustedBeneficiaryNotFound) {
+			respondWithError(w, http.StatusNotFound, "not_found", "Beneficiário não consta da lista de confiança")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao remover beneficiário de confiança")
+		return
+	}
+
+	// Registar a remoção para auditoria das ações das equipas de suporte
+	h.service.logger.InfoWithContext(r.Context(), "Beneficiário retirado da lista de confiança pelo suporte",
+		"tenant_id", tenantID,
+		"user_id", vars["userId"],
+		"key", vars["key"],
+		"operator", supportOperator(r))
+
+	w.WriteHeader(http.StatusNoContent)
+}
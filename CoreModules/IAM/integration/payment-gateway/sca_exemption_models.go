@@ -0,0 +1,122 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Isenções de autenticação forte do PSD2 (RTS, Regulamento Delegado (UE) 2018/389)
+const (
+	SCAExemptionNone               = "none"
+	SCAExemptionLowValue           = "low_value"           // Art. 16: pagamentos remotos de baixo valor
+	SCAExemptionTrustedBeneficiary = "trusted_beneficiary" // Art. 13: beneficiário na lista de confiança do pagador
+	SCAExemptionTRA                = "tra"                 // Art. 18: análise de risco da transação
+)
+
+// Resultados da avaliação de isenção SCA
+const (
+	SCAOutcomeExempted  = "exempted"      // Isenção aplicada; o pagamento segue sem SCA
+	SCAOutcomeRequired  = "sca_required"  // Nenhuma isenção aplicável; exige MFA de nível alto
+	SCAOutcomePerformed = "sca_performed" // O pagador autenticou-se com SCA (MFA de nível alto)
+)
+
+// Instrumentos com taxa de fraude própria (Art. 19)
+const (
+	SCAInstrumentCard           = "card"
+	SCAInstrumentCreditTransfer = "credit_transfer"
+)
+
+// Limites das isenções e valores padrão do serviço
+const (
+	SCALowValueMaxAmount            = 30.0                // Valor máximo por pagamento de baixo valor (EUR)
+	SCALowValueMaxCount             = 5                   // Pagamentos isentos por baixo valor desde a última SCA
+	SCALowValueMaxCumulative        = 100.0               // Valor acumulado isento por baixo valor desde a última SCA (EUR)
+	DefaultSCAFraudRateWindow       = 90 * 24 * time.Hour // Período móvel da taxa de fraude (trimestral)
+	DefaultSCADecisionRetention     = 180 * 24 * time.Hour
+	DefaultSCADecisionPruneInterval = time.Hour
+)
+
+// Erros do serviço de isenções SCA
+var (
+	ErrSCADecisionNotFound           = errors.New("decisão de isenção SCA não encontrada para a transação")
+	ErrSCATransactionNotCompleted    = errors.New("transação não concluída")
+	ErrSCATrustedBeneficiaryNotFound = errors.New("beneficiário não consta da lista de confiança")
+)
+
+// SCATRAThreshold é uma faixa de valor da isenção TRA com as taxas de fraude de referência (Anexo do RTS)
+type SCATRAThreshold struct {
+	MaxAmount          float64 `json:"max_amount"`
+	CardRate           float64 `json:"card_rate"`
+	CreditTransferRate float64 `json:"credit_transfer_rate"`
+}
+
+// DefaultSCATRAThresholds retorna as faixas da isenção TRA por ordem crescente de valor
+func DefaultSCATRAThresholds() []SCATRAThreshold {
+	return []SCATRAThreshold{
+		{MaxAmount: 100, CardRate: 0.0013, CreditTransferRate: 0.00015},
+		{MaxAmount: 250, CardRate: 0.0006, CreditTransferRate: 0.0001},
+		{MaxAmount: 500, CardRate: 0.0001, CreditTransferRate: 0.00005},
+	}
+}
+
+// SCAExemptionConfig contém configurações do serviço de isenções SCA
+type SCAExemptionConfig struct {
+	FraudRateWindow   time.Duration     `json:"fraud_rate_window"`  // Período móvel da taxa de fraude da isenção TRA
+	DecisionRetention time.Duration     `json:"decision_retention"` // Retenção das decisões para o reporte ao adquirente
+	PruneInterval     time.Duration     `json:"prune_interval"`     // Periodicidade da remoção das decisões expiradas
+	TRAThresholds     []SCATRAThreshold `json:"tra_thresholds"`     // Faixas da isenção TRA por ordem crescente de valor
+}
+
+// SCAExemptionDecision regista a decisão de isenção SCA de um pagamento para o reporte ao adquirente
+type SCAExemptionDecision struct {
+	TenantID           string    `json:"tenant_id" db:"tenant_id"`
+	TransactionID      string    `json:"transaction_id" db:"transaction_id"`
+	MerchantID         string    `json:"merchant_id" db:"merchant_id"`
+	UserID             string    `json:"user_id" db:"user_id"`
+	PaymentMethod      string    `json:"payment_method" db:"payment_method"`
+	Instrument         string    `json:"instrument" db:"instrument"`
+	Amount             float64   `json:"amount" db:"amount"`
+	Currency           string    `json:"currency" db:"currency"`
+	Exemption          string    `json:"exemption" db:"exemption"`
+	Outcome            string    `json:"outcome" db:"outcome"`
+	Reason             string    `json:"reason" db:"reason"`
+	FraudRate          float64   `json:"fraud_rate,omitempty" db:"fraud_rate"`                     // Taxa de fraude do instrumento no período (TRA)
+	FraudRateThreshold float64   `json:"fraud_rate_threshold,omitempty" db:"fraud_rate_threshold"` // Taxa de referência da faixa de valor (TRA)
+	RiskScore          float64   `json:"risk_score,omitempty" db:"risk_score"`                     // Score de risco em tempo real (TRA)
+	LowValueCount      int       `json:"low_value_count,omitempty" db:"low_value_count"`           // Pagamentos isentos desde a última SCA, incluindo este
+	LowValueCumulative float64   `json:"low_value_cumulative,omitempty" db:"low_value_cumulative"` // Valor isento desde a última SCA, incluindo este
+	Completed          bool      `json:"completed" db:"completed"`
+	Fraudulent         bool      `json:"fraudulent" db:"fraudulent"`
+	DecidedAt          time.Time `json:"decided_at" db:"decided_at"`
+}
+
+// SCADecisionFilter restringe a listagem das decisões; campos vazios não filtram
+type SCADecisionFilter struct {
+	TenantID   string
+	MerchantID string
+	From       time.Time // Inclusivo
+	To         time.Time // Exclusivo
+}
+
+// SCALowValueCounter acumula os pagamentos isentos por baixo valor de um instrumento desde a última SCA
+type SCALowValueCounter struct {
+	Count      int     `json:"count" db:"count"`
+	Cumulative float64 `json:"cumulative" db:"cumulative"`
+}
+
+// TrustedBeneficiary é um beneficiário na lista de confiança de um pagador
+type TrustedBeneficiary struct {
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	Key           string    `json:"key" db:"beneficiary_key"`           // "merchant:<id>" ou "iban:<iban>"
+	TransactionID string    `json:"transaction_id" db:"transaction_id"` // Pagamento autenticado com SCA que adicionou o beneficiário
+	AddedAt       time.Time `json:"added_at" db:"added_at"`
+}
+
+// SCAFraudRate é a taxa de fraude de um instrumento no período móvel
+type SCAFraudRate struct {
+	Instrument  string  `json:"instrument"`
+	TotalAmount float64 `json:"total_amount"`
+	FraudAmount float64 `json:"fraud_amount"`
+	Rate        float64 `json:"rate"`
+}
@@ -0,0 +1,46 @@
+package paymentgateway
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// scaExemptionReportColumnNames são as colunas do reporte de isenções ao adquirente
+var scaExemptionReportColumnNames = []string{
+	"transaction_id", "merchant_id", "decided_at", "payment_method", "instrument", "amount", "currency",
+	"exemption", "outcome", "fraud_rate", "fraud_rate_threshold", "risk_score", "completed", "fraudulent", "reason",
+}
+
+// WriteSCAExemptionReportCSV escreve as decisões no formato do reporte de isenções ao adquirente
+func WriteSCAExemptionReportCSV(w io.Writer, decisions []*SCAExemptionDecision) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(scaExemptionReportColumnNames); err != nil {
+		return err
+	}
+	for _, d := range decisions {
+		row := []string{
+			d.TransactionID,
+			d.MerchantID,
+			d.DecidedAt.UTC().Format(time.RFC3339),
+			d.PaymentMethod,
+			d.Instrument,
+			strconv.FormatFloat(d.Amount, 'f', 2, 64),
+			d.Currency,
+			d.Exemption,
+			d.Outcome,
+			strconv.FormatFloat(d.FraudRate, 'f', 6, 64),
+			strconv.FormatFloat(d.FraudRateThreshold, 'f', 6, 64),
+			strconv.FormatFloat(d.RiskScore, 'f', 2, 64),
+			strconv.FormatBool(d.Completed),
+			strconv.FormatBool(d.Fraudulent),
+			d.Reason,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
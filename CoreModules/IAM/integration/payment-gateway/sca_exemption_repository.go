@@ -0,0 +1,244 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresSCAExemptionStore implementa SCAExemptionStore para PostgreSQL
+type PostgresSCAExemptionStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresSCAExemptionStore cria uma nova instância de PostgresSCAExemptionStore
+func NewPostgresSCAExemptionStore(db *sqlx.DB) *PostgresSCAExemptionStore {
+	return &PostgresSCAExemptionStore{db: db}
+}
+
+// scaDecisionColumns são as colunas lidas nas consultas de decisões
+const scaDecisionColumns = `tenant_id, transaction_id, merchant_id, user_id, payment_method, instrument, amount,
+	currency, exemption, outcome, reason, fraud_rate, fraud_rate_threshold, risk_score, low_value_count,
+	low_value_cumulative, completed, fraudulent, decided_at`
+
+// SaveSCADecision grava a decisão, substituindo a de uma avaliação anterior do mesmo pagamento
+func (r *PostgresSCAExemptionStore) SaveSCADecision(ctx context.Context, decision *SCAExemptionDecision) error {
+	query := `
+		INSERT INTO payment_gateway.sca_decisions (
+			tenant_id, transaction_id, merchant_id, user_id, payment_method, instrument, amount,
+			currency, exemption, outcome, reason, fraud_rate, fraud_rate_threshold, risk_score, low_value_count,
+			low_value_cumulative, completed, fraudulent, decided_at
+		) VALUES (
+			:tenant_id, :transaction_id, :merchant_id, :user_id, :payment_method, :instrument, :amount,
+			:currency, :exemption, :outcome, :reason, :fraud_rate, :fraud_rate_threshold, :risk_score, :low_value_count,
+			:low_value_cumulative, :completed, :fraudulent, :decided_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			merchant_id = EXCLUDED.merchant_id,
+			user_id = EXCLUDED.user_id,
+			payment_method = EXCLUDED.payment_method,
+			instrument = EXCLUDED.instrument,
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			exemption = EXCLUDED.exemption,
+			outcome = EXCLUDED.outcome,
+			reason = EXCLUDED.reason,
+			fraud_rate = EXCLUDED.fraud_rate,
+			fraud_rate_threshold = EXCLUDED.fraud_rate_threshold,
+			risk_score = EXCLUDED.risk_score,
+			low_value_count = EXCLUDED.low_value_count,
+			low_value_cumulative = EXCLUDED.low_value_cumulative,
+			completed = EXCLUDED.completed,
+			fraudulent = EXCLUDED.fraudulent,
+			decided_at = EXCLUDED.decided_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, decision); err != nil {
+		return fmt.Errorf("falha ao gravar decisão de isenção SCA: %w", err)
+	}
+
+	return nil
+}
+
+// GetSCADecision recupera a decisão do pagamento
+func (r *PostgresSCAExemptionStore) GetSCADecision(ctx context.Context, tenantID, transactionID string) (*SCAExemptionDecision, error) {
+	var decision SCAExemptionDecision
+	query := `SELECT ` + scaDecisionColumns + ` FROM payment_gateway.sca_decisions
+		WHERE tenant_id = $1 AND transaction_id = $2`
+	if err := r.db.GetContext(ctx, &decision, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSCADecisionNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar decisão de isenção SCA: %w", err)
+	}
+	return &decision, nil
+}
+
+// ListSCADecisions lista as decisões do filtro por ordem de decisão
+func (r *PostgresSCAExemptionStore) ListSCADecisions(ctx context.Context, filter SCADecisionFilter) ([]*SCAExemptionDecision, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", column, len(args)))
+	}
+	if filter.TenantID != "" {
+		addCondition("tenant_id =", filter.TenantID)
+	}
+	if filter.MerchantID != "" {
+		addCondition("merchant_id =", filter.MerchantID)
+	}
+	if !filter.From.IsZero() {
+		addCondition("decided_at >=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("decided_at <", filter.To)
+	}
+
+	var decisions []*SCAExemptionDecision
+	query := `SELECT ` + scaDecisionColumns + ` FROM payment_gateway.sca_decisions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY decided_at`
+	if err := r.db.SelectContext(ctx, &decisions, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao listar decisões de isenção SCA: %w", err)
+	}
+	return decisions, nil
+}
+
+// SCAVolume soma os pagamentos concluídos do instrumento no período
+func (r *PostgresSCAExemptionStore) SCAVolume(ctx context.Context, tenantID, instrument string, since time.Time) (float64, float64, error) {
+	var volume struct {
+		Total float64 `db:"total"`
+		Fraud float64 `db:"fraud"`
+	}
+	query := `
+		SELECT COALESCE(SUM(amount), 0) AS total,
+			COALESCE(SUM(amount) FILTER (WHERE fraudulent), 0) AS fraud
+		FROM payment_gateway.sca_decisions
+		WHERE tenant_id = $1 AND instrument = $2 AND completed AND decided_at >= $3`
+	if err := r.db.GetContext(ctx, &volume, query, tenantID, instrument, since); err != nil {
+		return 0, 0, fmt.Errorf("falha ao calcular volume do instrumento: %w", err)
+	}
+	return volume.Total, volume.Fraud, nil
+}
+
+// DeleteSCADecisionsBefore remove as decisões anteriores a cutoff
+func (r *PostgresSCAExemptionStore) DeleteSCADecisionsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM payment_gateway.sca_decisions WHERE decided_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao remover decisões de isenção SCA: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("falha ao contar decisões removidas: %w", err)
+	}
+	return int(removed), nil
+}
+
+// ReserveSCALowValue soma o pagamento ao contador numa única instrução, para que instâncias
+// concorrentes não excedam os limites de baixo valor
+func (r *PostgresSCAExemptionStore) ReserveSCALowValue(ctx context.Context, tenantID, instrumentKey string, amount float64) (SCALowValueCounter, bool, error) {
+	var counter SCALowValueCounter
+	query := `
+		INSERT INTO payment_gateway.sca_low_value_counters (tenant_id, instrument_key, count, cumulative)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (tenant_id, instrument_key) DO UPDATE SET
+			count = sca_low_value_counters.count + 1,
+			cumulative = sca_low_value_counters.cumulative + EXCLUDED.cumulative
+		WHERE sca_low_value_counters.count < $4
+			AND sca_low_value_counters.cumulative + EXCLUDED.cumulative <= $5
+		RETURNING count, cumulative`
+	err := r.db.GetContext(ctx, &counter, query, tenantID, instrumentKey, amount,
+		SCALowValueMaxCount, SCALowValueMaxCumulative)
+	if err == nil {
+		return counter, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return counter, false, fmt.Errorf("falha ao reservar contador de baixo valor: %w", err)
+	}
+
+	// Limite atingido: retornar o contador atual para a justificação da decisão
+	query = `SELECT count, cumulative FROM payment_gateway.sca_low_value_counters
+		WHERE tenant_id = $1 AND instrument_key = $2`
+	if err := r.db.GetContext(ctx, &counter, query, tenantID, instrumentKey); err != nil {
+		return counter, false, fmt.Errorf("falha ao recuperar contador de baixo valor: %w", err)
+	}
+	return counter, false, nil
+}
+
+// ResetSCALowValue reinicia o contador do instrumento
+func (r *PostgresSCAExemptionStore) ResetSCALowValue(ctx context.Context, tenantID, instrumentKey string) error {
+	query := `DELETE FROM payment_gateway.sca_low_value_counters WHERE tenant_id = $1 AND instrument_key = $2`
+	if _, err := r.db.ExecContext(ctx, query, tenantID, instrumentKey); err != nil {
+		return fmt.Errorf("falha ao reiniciar contador de baixo valor: %w", err)
+	}
+	return nil
+}
+
+// SaveTrustedBeneficiary adiciona o beneficiário à lista de confiança do pagador
+func (r *PostgresSCAExemptionStore) SaveTrustedBeneficiary(ctx context.Context, beneficiary *TrustedBeneficiary) error {
+	query := `
+		INSERT INTO payment_gateway.sca_trusted_beneficiaries (
+			tenant_id, user_id, beneficiary_key, transaction_id, added_at
+		) VALUES (
+			:tenant_id, :user_id, :beneficiary_key, :transaction_id, :added_at
+		)
+		ON CONFLICT (tenant_id, user_id, beneficiary_key) DO UPDATE SET
+			transaction_id = EXCLUDED.transaction_id,
+			added_at = EXCLUDED.added_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, beneficiary); err != nil {
+		return fmt.Errorf("falha ao gravar beneficiário de confiança: %w", err)
+	}
+
+	return nil
+}
+
+// IsTrustedBeneficiary indica se o beneficiário consta da lista de confiança do pagador
+func (r *PostgresSCAExemptionStore) IsTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM payment_gateway.sca_trusted_beneficiaries
+		WHERE tenant_id = $1 AND user_id = $2 AND beneficiary_key = $3)`
+	if err := r.db.GetContext(ctx, &exists, query, tenantID, userID, key); err != nil {
+		return false, fmt.Errorf("falha ao verificar beneficiário de confiança: %w", err)
+	}
+	return exists, nil
+}
+
+// ListTrustedBeneficiaries lista a lista de confiança do pagador ordenada por chave
+func (r *PostgresSCAExemptionStore) ListTrustedBeneficiaries(ctx context.Context, tenantID, userID string) ([]*TrustedBeneficiary, error) {
+	beneficiaries := []*TrustedBeneficiary{}
+	query := `SELECT tenant_id, user_id, beneficiary_key, transaction_id, added_at
+		FROM payment_gateway.sca_trusted_beneficiaries
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY beneficiary_key`
+	if err := r.db.SelectContext(ctx, &beneficiaries, query, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("falha ao listar beneficiários de confiança: %w", err)
+	}
+	return beneficiaries, nil
+}
+
+// DeleteTrustedBeneficiary retira o beneficiário da lista de confiança do pagador
+func (r *PostgresSCAExemptionStore) DeleteTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) error {
+	query := `DELETE FROM payment_gateway.sca_trusted_beneficiaries
+		WHERE tenant_id = $1 AND user_id = $2 AND beneficiary_key = $3`
+	result, err := r.db.ExecContext(ctx, query, tenantID, userID, key)
+	if err != nil {
+		return fmt.Errorf("falha ao remover beneficiário de confiança: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao contar beneficiários removidos: %w", err)
+	}
+	if removed == 0 {
+		return ErrSCATrustedBeneficiaryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,434 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// SCAExemptionService decide as isenções SCA dos pagamentos remotos em EUR no mercado UE e mantém
+// as taxas de fraude por instrumento e as listas de beneficiários de confiança
+type SCAExemptionService struct {
+	config     SCAExemptionConfig
+	store      SCAExemptionStore
+	riskEngine *RiskEngine
+
+	// Serializa a conclusão e o reporte de fraude, que atualizam a mesma decisão
+	mutex sync.Mutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewSCAExemptionService cria o serviço de isenções SCA; a isenção TRA usa as regras do motor de risco
+func NewSCAExemptionService(config SCAExemptionConfig, store SCAExemptionStore, riskEngine *RiskEngine) (*SCAExemptionService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-sca-exemptions",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.FraudRateWindow <= 0 {
+		config.FraudRateWindow = DefaultSCAFraudRateWindow
+	}
+	if config.DecisionRetention <= 0 {
+		config.DecisionRetention = DefaultSCADecisionRetention
+	}
+	if config.PruneInterval <= 0 {
+		config.PruneInterval = DefaultSCADecisionPruneInterval
+	}
+	if len(config.TRAThresholds) == 0 {
+		config.TRAThresholds = DefaultSCATRAThresholds()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SCAExemptionService{
+		config:          config,
+		store:           store,
+		riskEngine:      riskEngine,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start inicia a remoção periódica das decisões fora do período de retenção
+func (s *SCAExemptionService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.PruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.PruneDecisions(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Remoção de decisões de isenção SCA iniciada", "interval", s.config.PruneInterval.String())
+}
+
+// Stop interrompe a remoção periódica
+func (s *SCAExemptionService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Evaluate decide se o pagamento exige SCA ou segue com uma isenção, verificadas pela ordem:
+// baixo valor, beneficiário de confiança e TRA. Retorna nil para pagamentos fora do âmbito do PSD2
+func (s *SCAExemptionService) Evaluate(ctx context.Context, req *PaymentRequest) (*SCAExemptionDecision, error) {
+	if !scaInScope(req) {
+		return nil, nil
+	}
+
+	ctx, span := s.tracer.StartSpan(ctx, "SCAExemptionService.Evaluate")
+	defer span.End()
+
+	now := s.now().UTC()
+	decision := &SCAExemptionDecision{
+		TenantID:      req.TenantID,
+		TransactionID: req.TransactionID,
+		MerchantID:    req.MerchantID,
+		UserID:        req.UserID,
+		PaymentMethod: req.PaymentMethod,
+		Instrument:    scaInstrument(req.PaymentMethod),
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Exemption:     SCAExemptionNone,
+		DecidedAt:     now,
+	}
+
+	instrumentKey := scaPaymentInstrumentKey(req)
+	if req.MFALevel == "high" {
+		// A SCA reinicia o contador de baixo valor do instrumento
+		if err := s.store.ResetSCALowValue(ctx, req.TenantID, instrumentKey); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		decision.Outcome = SCAOutcomePerformed
+		decision.Reason = "autenticação forte realizada pelo pagador"
+		return s.save(ctx, decision)
+	}
+
+	var reasons []string
+
+	// Art. 16: até 30 EUR, no máximo 5 pagamentos ou 100 EUR acumulados desde a última SCA.
+	// O contador é reservado na decisão, pelo que um pagamento que falhe depois continua a contar
+	if req.Amount <= SCALowValueMaxAmount {
+		counter, reserved, err := s.store.ReserveSCALowValue(ctx, req.TenantID, instrumentKey, req.Amount)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if reserved {
+			decision.LowValueCount = counter.Count
+			decision.LowValueCumulative = counter.Cumulative
+			return s.exempt(ctx, decision, SCAExemptionLowValue, fmt.Sprintf(
+				"baixo valor: %d pagamentos e %.2f EUR isentos desde a última SCA", counter.Count, counter.Cumulative))
+		}
+		reasons = append(reasons, fmt.Sprintf("limite de baixo valor atingido (%d pagamentos, %.2f EUR desde a última SCA)",
+			counter.Count, counter.Cumulative))
+	}
+
+	// Art. 13: beneficiário adicionado pelo pagador à lista de confiança com SCA
+	if key := scaBeneficiaryKey(req); key != "" {
+		trusted, err := s.store.IsTrustedBeneficiary(ctx, req.TenantID, req.UserID, key)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if trusted {
+			return s.exempt(ctx, decision, SCAExemptionTrustedBeneficiary, "beneficiário de confiança "+key)
+		}
+	}
+
+	// Art. 18: taxa de fraude do instrumento dentro da referência da faixa de valor e risco baixo
+	threshold, ok := s.traThresholdFor(decision.Instrument, req.Amount)
+	if !ok {
+		reasons = append(reasons, fmt.Sprintf("valor %.2f EUR acima do limite da isenção TRA", req.Amount))
+	} else {
+		rate, err := s.fraudRate(ctx, req.TenantID, decision.Instrument)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		decision.FraudRate = rate.Rate
+		decision.FraudRateThreshold = threshold
+		switch {
+		case rate.TotalAmount == 0:
+			reasons = append(reasons, "taxa de fraude sem volume de referência no período")
+		case rate.Rate > threshold:
+			reasons = append(reasons, fmt.Sprintf("taxa de fraude %.4f%% acima da referência %.4f%%",
+				rate.Rate*100, threshold*100))
+		case s.riskEngine == nil:
+			reasons = append(reasons, "motor de risco indisponível para a análise em tempo real")
+		default:
+			explanation := s.riskEngine.evaluateRules(ctx, req, s.traRuleSet())
+			decision.RiskScore = explanation.RiskScore
+			if explanation.Decision == RiskDecisionApproved {
+				return s.exempt(ctx, decision, SCAExemptionTRA, fmt.Sprintf(
+					"TRA: taxa de fraude %.4f%% (referência %.4f%%), score de risco %.2f",
+					rate.Rate*100, threshold*100, explanation.RiskScore))
+			}
+			reasons = append(reasons, fmt.Sprintf("score de risco %.2f não é baixo", explanation.RiskScore))
+		}
+	}
+
+	decision.Outcome = SCAOutcomeRequired
+	decision.Reason = "nenhuma isenção aplicável: " + strings.Join(reasons, "; ")
+	return s.save(ctx, decision)
+}
+
+// exempt grava a decisão com a isenção aplicada
+func (s *SCAExemptionService) exempt(ctx context.Context, decision *SCAExemptionDecision, exemption, reason string) (*SCAExemptionDecision, error) {
+	decision.Exemption = exemption
+	decision.Outcome = SCAOutcomeExempted
+	decision.Reason = reason
+	return s.save(ctx, decision)
+}
+
+// save grava a decisão, substituindo a de uma avaliação anterior do mesmo pagamento
+func (s *SCAExemptionService) save(ctx context.Context, decision *SCAExemptionDecision) (*SCAExemptionDecision, error) {
+	if err := s.store.SaveSCADecision(ctx, decision); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_sca_exemptions_total", map[string]string{
+		"exemption": decision.Exemption,
+		"outcome":   decision.Outcome,
+	})
+	s.logger.InfoWithContext(ctx, "Decisão de isenção SCA registada",
+		"transaction_id", decision.TransactionID,
+		"exemption", decision.Exemption,
+		"outcome", decision.Outcome,
+		"reason", decision.Reason)
+	return decision, nil
+}
+
+// RecordCompleted contabiliza o pagamento concluído no volume do instrumento (denominador da taxa
+// de fraude) e, quando o pagador autenticado com SCA o pediu em metadata.trust_beneficiary,
+// adiciona o beneficiário à sua lista de confiança
+func (s *SCAExemptionService) RecordCompleted(ctx context.Context, req *PaymentRequest) error {
+	if req.SCAExemption == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	decision, err := s.store.GetSCADecision(ctx, req.TenantID, req.TransactionID)
+	if err != nil {
+		return err
+	}
+	if decision.Completed {
+		return nil
+	}
+	decision.Completed = true
+	if err := s.store.SaveSCADecision(ctx, decision); err != nil {
+		return err
+	}
+
+	if trust, _ := req.Metadata["trust_beneficiary"].(bool); !trust || decision.Outcome != SCAOutcomePerformed {
+		return nil
+	}
+	key := scaBeneficiaryKey(req)
+	if key == "" {
+		return nil
+	}
+	return s.store.SaveTrustedBeneficiary(ctx, &TrustedBeneficiary{
+		TenantID:      req.TenantID,
+		UserID:        req.UserID,
+		Key:           key,
+		TransactionID: req.TransactionID,
+		AddedAt:       decision.DecidedAt,
+	})
+}
+
+// ReportFraud marca como fraudulento um pagamento concluído, somando o seu valor à fraude do
+// instrumento. Reportes repetidos não alteram a taxa
+func (s *SCAExemptionService) ReportFraud(ctx context.Context, tenantID, transactionID string) (*SCAExemptionDecision, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	decision, err := s.store.GetSCADecision(ctx, tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Completed {
+		return nil, ErrSCATransactionNotCompleted
+	}
+	if decision.Fraudulent {
+		return decision, nil
+	}
+
+	decision.Fraudulent = true
+	if err := s.store.SaveSCADecision(ctx, decision); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// FraudRates retorna a taxa de fraude de cada instrumento do tenant no período móvel
+func (s *SCAExemptionService) FraudRates(ctx context.Context, tenantID string) ([]SCAFraudRate, error) {
+	rates := make([]SCAFraudRate, 0, 2)
+	for _, instrument := range []string{SCAInstrumentCard, SCAInstrumentCreditTransfer} {
+		rate, err := s.fraudRate(ctx, tenantID, instrument)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// Decisions lista as decisões do filtro para o reporte ao adquirente
+func (s *SCAExemptionService) Decisions(ctx context.Context, filter SCADecisionFilter) ([]*SCAExemptionDecision, error) {
+	return s.store.ListSCADecisions(ctx, filter)
+}
+
+// TrustedBeneficiaries retorna a lista de confiança do pagador ordenada por chave
+func (s *SCAExemptionService) TrustedBeneficiaries(ctx context.Context, tenantID, userID string) ([]*TrustedBeneficiary, error) {
+	return s.store.ListTrustedBeneficiaries(ctx, tenantID, userID)
+}
+
+// RemoveTrustedBeneficiary retira um beneficiário da lista de confiança do pagador
+func (s *SCAExemptionService) RemoveTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) error {
+	return s.store.DeleteTrustedBeneficiary(ctx, tenantID, userID, key)
+}
+
+// PruneDecisions remove as decisões fora do período de retenção
+func (s *SCAExemptionService) PruneDecisions(ctx context.Context) int {
+	removed, err := s.store.DeleteSCADecisionsBefore(ctx, s.now().UTC().Add(-s.config.DecisionRetention))
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao remover decisões de isenção SCA expiradas", "error", err.Error())
+		return 0
+	}
+	if removed > 0 {
+		s.logger.InfoWithContext(ctx, "Decisões de isenção SCA expiradas removidas", "removed", removed)
+	}
+	return removed
+}
+
+// fraudRate calcula a taxa de fraude do instrumento no período móvel terminado agora
+func (s *SCAExemptionService) fraudRate(ctx context.Context, tenantID, instrument string) (SCAFraudRate, error) {
+	total, fraud, err := s.store.SCAVolume(ctx, tenantID, instrument, s.now().UTC().Add(-s.config.FraudRateWindow))
+	if err != nil {
+		return SCAFraudRate{}, err
+	}
+
+	rate := SCAFraudRate{
+		Instrument:  instrument,
+		TotalAmount: roundAmount(total),
+		FraudAmount: roundAmount(fraud),
+	}
+	if rate.TotalAmount > 0 {
+		rate.Rate = rate.FraudAmount / rate.TotalAmount
+	}
+	return rate, nil
+}
+
+// traThresholdFor retorna a taxa de fraude de referência da faixa de valor do pagamento
+func (s *SCAExemptionService) traThresholdFor(instrument string, amount float64) (float64, bool) {
+	for _, threshold := range s.config.TRAThresholds {
+		if amount <= threshold.MaxAmount {
+			if instrument == SCAInstrumentCreditTransfer {
+				return threshold.CreditTransferRate, true
+			}
+			return threshold.CardRate, true
+		}
+	}
+	return 0, false
+}
+
+// traRuleSet é o conjunto de regras em produção sem a regra de conformidade SCA,
+// que depende da própria decisão de isenção
+func (s *SCAExemptionService) traRuleSet() RiskRuleSet {
+	disabled := false
+	ruleSet := s.riskEngine.CurrentRuleSet()
+	ruleSet.Rules = map[string]RiskRuleOverride{"eu_sca_compliance": {Enabled: &disabled}}
+	return ruleSet
+}
+
+// scaInScope indica se o pagamento é remoto, em EUR e no mercado UE, e portanto sujeito a SCA
+func scaInScope(req *PaymentRequest) bool {
+	// As MIT estão fora do âmbito da SCA: o titular autenticou-se na CIT inicial que armazenou o cartão
+	if req.RegionCode != RegionEU || req.Currency != "EUR" || storedCredentialMerchantInitiated(req) {
+		return false
+	}
+	switch req.PaymentMethod {
+	case PaymentMethodCard, PaymentMethodBankTransfer, PaymentMethodSEPA, PaymentMethodDigitalWallet:
+		return true
+	}
+	return false
+}
+
+// scaRequired indica se o pagamento exige autenticação forte, segundo a decisão de isenção quando
+// avaliada ou, sem decisão, pelo limiar de baixo valor de 30 EUR
+func scaRequired(req *PaymentRequest) bool {
+	if storedCredentialMerchantInitiated(req) {
+		return false
+	}
+	if req.SCAExemption != nil {
+		return req.SCAExemption.Outcome != SCAOutcomeExempted
+	}
+	return req.Currency == "EUR" && req.Amount > scaAmountThreshold
+}
+
+// scaInstrument mapeia o meio de pagamento para o instrumento da taxa de fraude
+func scaInstrument(paymentMethod string) string {
+	if paymentMethod == PaymentMethodBankTransfer || paymentMethod == PaymentMethodSEPA {
+		return SCAInstrumentCreditTransfer
+	}
+	return SCAInstrumentCard
+}
+
+// scaPaymentInstrumentKey identifica o instrumento do pagador no contador de baixo valor:
+// o token ou PAN truncado do cartão, ou a conta do usuário nos restantes meios de pagamento
+func scaPaymentInstrumentKey(req *PaymentRequest) string {
+	if req.CardTokenID != "" {
+		return "card:" + req.CardTokenID
+	}
+	if card, ok := req.PaymentDetails["card"].(map[string]interface{}); ok {
+		if token, _ := card["token"].(string); token != "" {
+			return "card:" + token
+		}
+		if pan, _ := card["truncated_pan"].(string); pan != "" {
+			return "card:" + req.UserID + ":" + pan
+		}
+	}
+	return "account:" + req.UserID + ":" + req.PaymentMethod
+}
+
+// scaBeneficiaryKey identifica o beneficiário: o IBAN nas transferências ou o comerciante
+func scaBeneficiaryKey(req *PaymentRequest) string {
+	if iban, _ := req.PaymentDetails["beneficiary_iban"].(string); iban != "" {
+		return "iban:" + strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	}
+	if req.MerchantID != "" {
+		return "merchant:" + req.MerchantID
+	}
+	return ""
+}
@@ -0,0 +1,457 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSCAExemptionService(t *testing.T) (*SCAExemptionService, *InMemorySCAExemptionStore, *time.Time) {
+	t.Helper()
+
+	store := NewInMemorySCAExemptionStore()
+	service, err := NewSCAExemptionService(SCAExemptionConfig{}, store, newTestRiskEngine(t, NewInMemoryTransactionRecordStore()))
+	require.NoError(t, err)
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func testSCARequest(transactionID string, amount float64) *PaymentRequest {
+	req := testRiskRequest(RegionEU, "EUR", amount)
+	req.TransactionID = transactionID
+	req.CardTokenID = "tok-1"
+	return req
+}
+
+// seedSCAVolume regista pagamentos concluídos do instrumento para a taxa de fraude da isenção TRA
+func seedSCAVolume(t *testing.T, store *InMemorySCAExemptionStore, instrument string, total, fraud float64, at time.Time) {
+	t.Helper()
+
+	for i, amount := range []float64{total - fraud, fraud} {
+		if amount <= 0 {
+			continue
+		}
+		require.NoError(t, store.SaveSCADecision(context.Background(), &SCAExemptionDecision{
+			TenantID:      "tenant-1",
+			TransactionID: "seed-" + instrument + "-" + string(rune('a'+i)),
+			Instrument:    instrument,
+			Amount:        amount,
+			Completed:     true,
+			Fraudulent:    i == 1,
+			DecidedAt:     at,
+		}))
+	}
+}
+
+func TestSCAExemptionEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time)
+		request   func() *PaymentRequest
+		exemption string
+		outcome   string
+		reason    string
+	}{
+		{
+			name: "MFA de nível alto regista a SCA realizada",
+			request: func() *PaymentRequest {
+				req := testSCARequest("tx-1", 80)
+				req.MFALevel = "high"
+				return req
+			},
+			exemption: SCAExemptionNone,
+			outcome:   SCAOutcomePerformed,
+		},
+		{
+			name:      "baixo valor isento",
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 25) },
+			exemption: SCAExemptionLowValue,
+			outcome:   SCAOutcomeExempted,
+			reason:    "1 pagamentos e 25.00 EUR",
+		},
+		{
+			name: "beneficiário de confiança isento",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				require.NoError(t, store.SaveTrustedBeneficiary(context.Background(), &TrustedBeneficiary{
+					TenantID: "tenant-1", UserID: "user-1", Key: "merchant:merchant-1", AddedAt: now,
+				}))
+			},
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 800) },
+			exemption: SCAExemptionTrustedBeneficiary,
+			outcome:   SCAOutcomeExempted,
+		},
+		{
+			name: "beneficiário de confiança de outro pagador não isenta",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				require.NoError(t, store.SaveTrustedBeneficiary(context.Background(), &TrustedBeneficiary{
+					TenantID: "tenant-1", UserID: "user-2", Key: "merchant:merchant-1", AddedAt: now,
+				}))
+			},
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 800) },
+			exemption: SCAExemptionNone,
+			outcome:   SCAOutcomeRequired,
+			reason:    "acima do limite da isenção TRA",
+		},
+		{
+			name: "TRA com taxa de fraude abaixo da referência",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				seedSCAVolume(t, store, SCAInstrumentCard, 100000, 50, now.Add(-24*time.Hour))
+			},
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 200) },
+			exemption: SCAExemptionTRA,
+			outcome:   SCAOutcomeExempted,
+		},
+		{
+			name: "TRA recusada com taxa de fraude acima da referência da faixa",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				seedSCAVolume(t, store, SCAInstrumentCard, 100000, 100, now.Add(-24*time.Hour))
+			},
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 200) },
+			exemption: SCAExemptionNone,
+			outcome:   SCAOutcomeRequired,
+			reason:    "acima da referência",
+		},
+		{
+			name: "TRA ignora volume fora do período móvel",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				seedSCAVolume(t, store, SCAInstrumentCard, 100000, 0, now.Add(-DefaultSCAFraudRateWindow-time.Hour))
+			},
+			request:   func() *PaymentRequest { return testSCARequest("tx-1", 200) },
+			exemption: SCAExemptionNone,
+			outcome:   SCAOutcomeRequired,
+			reason:    "sem volume de referência",
+		},
+		{
+			name: "TRA usa a taxa das transferências a crédito",
+			setup: func(t *testing.T, store *InMemorySCAExemptionStore, now time.Time) {
+				// 0,05% está abaixo da referência dos cartões, mas acima da das transferências (0,015%)
+				seedSCAVolume(t, store, SCAInstrumentCreditTransfer, 100000, 50, now.Add(-24*time.Hour))
+			},
+			request: func() *PaymentRequest {
+				req := testSCARequest("tx-1", 80)
+				req.PaymentMethod = PaymentMethodBankTransfer
+				req.PaymentDetails = map[string]interface{}{"beneficiary_iban": "de89 3704 0044 0532 0130 00"}
+				return req
+			},
+			exemption: SCAExemptionNone,
+			outcome:   SCAOutcomeRequired,
+			reason:    "acima da referência",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, store, clock := newTestSCAExemptionService(t)
+			if tt.setup != nil {
+				tt.setup(t, store, *clock)
+			}
+
+			decision, err := service.Evaluate(context.Background(), tt.request())
+			require.NoError(t, err)
+			require.NotNil(t, decision)
+			assert.Equal(t, tt.exemption, decision.Exemption)
+			assert.Equal(t, tt.outcome, decision.Outcome)
+			assert.Contains(t, decision.Reason, tt.reason)
+
+			stored, err := store.GetSCADecision(context.Background(), "tenant-1", "tx-1")
+			require.NoError(t, err)
+			assert.Equal(t, decision.Outcome, stored.Outcome)
+		})
+	}
+}
+
+func TestSCAExemptionEvaluateOutOfScope(t *testing.T) {
+	service, _, _ := newTestSCAExemptionService(t)
+
+	for _, req := range []*PaymentRequest{
+		testRiskRequest(RegionAngola, "EUR", 100),
+		testRiskRequest(RegionEU, "USD", 100),
+		func() *PaymentRequest {
+			req := testSCARequest("tx-1", 100)
+			req.PaymentMethod = PaymentMethodCrypto
+			return req
+		}(),
+	} {
+		decision, err := service.Evaluate(context.Background(), req)
+		require.NoError(t, err)
+		assert.Nil(t, decision)
+	}
+}
+
+func TestSCAExemptionLowValueLimits(t *testing.T) {
+	t.Run("limite de cinco pagamentos", func(t *testing.T) {
+		service, _, _ := newTestSCAExemptionService(t)
+
+		for i := 1; i <= SCALowValueMaxCount; i++ {
+			decision, err := service.Evaluate(context.Background(), testSCARequest("tx-"+string(rune('0'+i)), 10))
+			require.NoError(t, err)
+			assert.Equal(t, SCAExemptionLowValue, decision.Exemption)
+			assert.Equal(t, i, decision.LowValueCount)
+		}
+
+		decision, err := service.Evaluate(context.Background(), testSCARequest("tx-6", 10))
+		require.NoError(t, err)
+		assert.Equal(t, SCAOutcomeRequired, decision.Outcome)
+		assert.Contains(t, decision.Reason, "limite de baixo valor atingido (5 pagamentos, 50.00 EUR")
+	})
+
+	t.Run("limite de 100 EUR acumulados", func(t *testing.T) {
+		service, _, _ := newTestSCAExemptionService(t)
+
+		for _, id := range []string{"tx-1", "tx-2", "tx-3", "tx-4"} {
+			decision, err := service.Evaluate(context.Background(), testSCARequest(id, 25))
+			require.NoError(t, err)
+			assert.Equal(t, SCAExemptionLowValue, decision.Exemption)
+		}
+
+		decision, err := service.Evaluate(context.Background(), testSCARequest("tx-5", 1))
+		require.NoError(t, err)
+		assert.Equal(t, SCAOutcomeRequired, decision.Outcome)
+	})
+
+	t.Run("SCA reinicia o contador do instrumento", func(t *testing.T) {
+		service, _, _ := newTestSCAExemptionService(t)
+
+		for _, id := range []string{"tx-1", "tx-2", "tx-3", "tx-4", "tx-5"} {
+			_, err := service.Evaluate(context.Background(), testSCARequest(id, 10))
+			require.NoError(t, err)
+		}
+		strong := testSCARequest("tx-6", 10)
+		strong.MFALevel = "high"
+		_, err := service.Evaluate(context.Background(), strong)
+		require.NoError(t, err)
+
+		decision, err := service.Evaluate(context.Background(), testSCARequest("tx-7", 10))
+		require.NoError(t, err)
+		assert.Equal(t, SCAExemptionLowValue, decision.Exemption)
+		assert.Equal(t, 1, decision.LowValueCount)
+	})
+
+	t.Run("contadores separados por cartão", func(t *testing.T) {
+		service, _, _ := newTestSCAExemptionService(t)
+
+		for _, id := range []string{"tx-1", "tx-2", "tx-3", "tx-4", "tx-5"} {
+			_, err := service.Evaluate(context.Background(), testSCARequest(id, 10))
+			require.NoError(t, err)
+		}
+		other := testSCARequest("tx-6", 10)
+		other.CardTokenID = "tok-2"
+
+		decision, err := service.Evaluate(context.Background(), other)
+		require.NoError(t, err)
+		assert.Equal(t, SCAExemptionLowValue, decision.Exemption)
+	})
+}
+
+func TestSCAExemptionRecordCompletedAddsTrustedBeneficiary(t *testing.T) {
+	service, store, _ := newTestSCAExemptionService(t)
+	ctx := context.Background()
+
+	req := testSCARequest("tx-1", 800)
+	req.MFALevel = "high"
+	req.Metadata = map[string]interface{}{"trust_beneficiary": true}
+	decision, err := service.Evaluate(ctx, req)
+	require.NoError(t, err)
+	req.SCAExemption = decision
+	require.NoError(t, service.RecordCompleted(ctx, req))
+
+	stored, err := store.GetSCADecision(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.True(t, stored.Completed)
+
+	beneficiaries, err := service.TrustedBeneficiaries(ctx, "tenant-1", "user-1")
+	require.NoError(t, err)
+	require.Len(t, beneficiaries, 1)
+	assert.Equal(t, "merchant:merchant-1", beneficiaries[0].Key)
+
+	// O pagamento seguinte ao mesmo comerciante, sem MFA, fica isento
+	next, err := service.Evaluate(ctx, testSCARequest("tx-2", 800))
+	require.NoError(t, err)
+	assert.Equal(t, SCAExemptionTrustedBeneficiary, next.Exemption)
+
+	require.NoError(t, service.RemoveTrustedBeneficiary(ctx, "tenant-1", "user-1", "merchant:merchant-1"))
+	assert.ErrorIs(t, service.RemoveTrustedBeneficiary(ctx, "tenant-1", "user-1", "merchant:merchant-1"),
+		ErrSCATrustedBeneficiaryNotFound)
+}
+
+func TestSCAExemptionRecordCompletedRequiresStrongAuthenticationToTrust(t *testing.T) {
+	service, _, _ := newTestSCAExemptionService(t)
+	ctx := context.Background()
+
+	req := testSCARequest("tx-1", 25)
+	req.Metadata = map[string]interface{}{"trust_beneficiary": true}
+	decision, err := service.Evaluate(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, SCAOutcomeExempted, decision.Outcome)
+	req.SCAExemption = decision
+	require.NoError(t, service.RecordCompleted(ctx, req))
+
+	beneficiaries, err := service.TrustedBeneficiaries(ctx, "tenant-1", "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, beneficiaries)
+}
+
+func TestSCAExemptionReportFraud(t *testing.T) {
+	service, _, _ := newTestSCAExemptionService(t)
+	ctx := context.Background()
+
+	_, err := service.ReportFraud(ctx, "tenant-1", "tx-1")
+	assert.ErrorIs(t, err, ErrSCADecisionNotFound)
+
+	req := testSCARequest("tx-1", 25)
+	decision, err := service.Evaluate(ctx, req)
+	require.NoError(t, err)
+	_, err = service.ReportFraud(ctx, "tenant-1", "tx-1")
+	assert.ErrorIs(t, err, ErrSCATransactionNotCompleted)
+
+	req.SCAExemption = decision
+	require.NoError(t, service.RecordCompleted(ctx, req))
+	other := testSCARequest("tx-2", 75)
+	other.MFALevel = "high"
+	other.SCAExemption, err = service.Evaluate(ctx, other)
+	require.NoError(t, err)
+	require.NoError(t, service.RecordCompleted(ctx, other))
+
+	for i := 0; i < 2; i++ {
+		reported, err := service.ReportFraud(ctx, "tenant-1", "tx-1")
+		require.NoError(t, err)
+		assert.True(t, reported.Fraudulent)
+	}
+
+	rates, err := service.FraudRates(ctx, "tenant-1")
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, SCAFraudRate{Instrument: SCAInstrumentCard, TotalAmount: 100, FraudAmount: 25, Rate: 0.25}, rates[0])
+	assert.Equal(t, SCAFraudRate{Instrument: SCAInstrumentCreditTransfer}, rates[1])
+}
+
+func TestSCAExemptionPruneDecisions(t *testing.T) {
+	service, store, clock := newTestSCAExemptionService(t)
+	ctx := context.Background()
+
+	_, err := service.Evaluate(ctx, testSCARequest("tx-1", 25))
+	require.NoError(t, err)
+	*clock = clock.Add(DefaultSCADecisionRetention - time.Hour)
+	_, err = service.Evaluate(ctx, testSCARequest("tx-2", 25))
+	require.NoError(t, err)
+
+	*clock = clock.Add(2 * time.Hour)
+	assert.Equal(t, 1, service.PruneDecisions(ctx))
+
+	_, err = store.GetSCADecision(ctx, "tenant-1", "tx-1")
+	assert.ErrorIs(t, err, ErrSCADecisionNotFound)
+	_, err = store.GetSCADecision(ctx, "tenant-1", "tx-2")
+	assert.NoError(t, err)
+}
+
+func TestWriteSCAExemptionReportCSV(t *testing.T) {
+	decisions := []*SCAExemptionDecision{{
+		TransactionID: "tx-1",
+		MerchantID:    "merchant-1",
+		PaymentMethod: PaymentMethodCard,
+		Instrument:    SCAInstrumentCard,
+		Amount:        120,
+		Currency:      "EUR",
+		Exemption:     SCAExemptionTRA,
+		Outcome:       SCAOutcomeExempted,
+		FraudRate:     0.0005,
+		RiskScore:     0.1,
+		Completed:     true,
+		Reason:        "TRA: taxa de fraude, score de risco",
+		DecidedAt:     time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSCAExemptionReportCSV(&buf, decisions))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, scaExemptionReportColumnNames, rows[0])
+	assert.Equal(t, []string{"tx-1", "merchant-1", "2026-10-16T12:00:00Z", "card", "card", "120.00", "EUR",
+		"tra", "exempted", "0.000500", "0.000000", "0.10", "true", "false", "TRA: taxa de fraude, score de risco"}, rows[1])
+}
+
+func TestSCAExemptionHandler(t *testing.T) {
+	service, _, _ := newTestSCAExemptionService(t)
+	ctx := context.Background()
+
+	req := testSCARequest("tx-1", 800)
+	req.MFALevel = "high"
+	req.Metadata = map[string]interface{}{"trust_beneficiary": true}
+	decision, err := service.Evaluate(ctx, req)
+	require.NoError(t, err)
+	req.SCAExemption = decision
+	require.NoError(t, service.RecordCompleted(ctx, req))
+
+	router := mux.NewRouter()
+	NewSCAExemptionHandler(service).RegisterRoutes(router)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("X-Tenant-ID", "tenant-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/support/sca/exemptions?format=csv&from=2026-10-16T00:00:00Z", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "tx-1,merchant-1,2026-10-16T12:00:00Z")
+
+	w = serve(http.MethodGet, "/support/sca/exemptions?from=ontem", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodPost, "/support/sca/fraud-reports", `{"transaction_id":"tx-9"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(http.MethodPost, "/support/sca/fraud-reports", `{"transaction_id":"tx-1"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(http.MethodGet, "/support/sca/trusted-beneficiaries/user-1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"merchant:merchant-1"`)
+
+	w = serve(http.MethodDelete, "/support/sca/trusted-beneficiaries/user-1?key=merchant:merchant-1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = serve(http.MethodDelete, "/support/sca/trusted-beneficiaries/user-1?key=merchant:merchant-1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProcessPaymentAppliesSCAExemption(t *testing.T) {
+	connector, _ := newTestConnector(t)
+	connector.SetRiskEngine(newTestRiskEngine(t, NewInMemoryTransactionRecordStore()))
+	service, _, _ := newTestSCAExemptionService(t)
+	connector.SetSCAExemptionService(service)
+
+	for i := 1; i <= SCALowValueMaxCount; i++ {
+		req := testSCARequest("tx-"+string(rune('0'+i)), 10)
+		req.RequestID = "req-" + string(rune('0'+i))
+
+		response, err := connector.ProcessPayment(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, TransactionStatusApproved, response.Status)
+		assert.Equal(t, SCAExemptionLowValue, response.Metadata["sca_exemption"])
+		assert.Equal(t, SCAExemptionLowValue, req.ThreeDSData["sca_exemption"])
+	}
+
+	// Com fraude reportada acima da referência da TRA e o limite de baixo valor esgotado,
+	// o pagamento sem MFA é rejeitado pela regra SCA
+	_, err := service.ReportFraud(context.Background(), "tenant-1", "tx-1")
+	require.NoError(t, err)
+	req := testSCARequest("tx-6", 10)
+	req.RequestID = "req-6"
+	response, err := connector.ProcessPayment(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusDenied, response.Status)
+	assert.Equal(t, "risco_rejeitado", response.StatusCode)
+}
@@ -0,0 +1,218 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SCAExemptionStore define a persistência das decisões de isenção SCA, dos contadores de baixo
+// valor e das listas de beneficiários de confiança
+type SCAExemptionStore interface {
+	// SaveSCADecision grava a decisão, substituindo a de uma avaliação anterior do mesmo pagamento
+	SaveSCADecision(ctx context.Context, decision *SCAExemptionDecision) error
+
+	// GetSCADecision recupera a decisão do pagamento; retorna ErrSCADecisionNotFound quando não existe
+	GetSCADecision(ctx context.Context, tenantID, transactionID string) (*SCAExemptionDecision, error)
+
+	// ListSCADecisions lista as decisões do filtro por ordem de decisão
+	ListSCADecisions(ctx context.Context, filter SCADecisionFilter) ([]*SCAExemptionDecision, error)
+
+	// SCAVolume soma o valor dos pagamentos concluídos do instrumento decididos desde since e a parte
+	// reportada como fraude
+	SCAVolume(ctx context.Context, tenantID, instrument string, since time.Time) (total, fraud float64, err error)
+
+	// DeleteSCADecisionsBefore remove as decisões anteriores a cutoff e retorna quantas foram removidas
+	DeleteSCADecisionsBefore(ctx context.Context, cutoff time.Time) (int, error)
+
+	// ReserveSCALowValue soma o pagamento ao contador do instrumento se os limites não forem excedidos
+	// Retorna o contador resultante, ou o atual quando a reserva é recusada
+	ReserveSCALowValue(ctx context.Context, tenantID, instrumentKey string, amount float64) (SCALowValueCounter, bool, error)
+
+	// ResetSCALowValue reinicia o contador do instrumento após uma SCA
+	ResetSCALowValue(ctx context.Context, tenantID, instrumentKey string) error
+
+	// SaveTrustedBeneficiary adiciona o beneficiário à lista de confiança do pagador
+	SaveTrustedBeneficiary(ctx context.Context, beneficiary *TrustedBeneficiary) error
+
+	// IsTrustedBeneficiary indica se o beneficiário consta da lista de confiança do pagador
+	IsTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) (bool, error)
+
+	// ListTrustedBeneficiaries lista a lista de confiança do pagador ordenada por chave
+	ListTrustedBeneficiaries(ctx context.Context, tenantID, userID string) ([]*TrustedBeneficiary, error)
+
+	// DeleteTrustedBeneficiary retira o beneficiário; retorna ErrSCATrustedBeneficiaryNotFound quando não existe
+	DeleteTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) error
+}
+
+// InMemorySCAExemptionStore armazena as isenções SCA em memória
+type InMemorySCAExemptionStore struct {
+	decisions     map[string]*SCAExemptionDecision
+	lowValue      map[string]SCALowValueCounter
+	beneficiaries map[string]*TrustedBeneficiary
+	mutex         sync.RWMutex
+}
+
+// NewInMemorySCAExemptionStore cria um novo armazenamento em memória
+func NewInMemorySCAExemptionStore() *InMemorySCAExemptionStore {
+	return &InMemorySCAExemptionStore{
+		decisions:     make(map[string]*SCAExemptionDecision),
+		lowValue:      make(map[string]SCALowValueCounter),
+		beneficiaries: make(map[string]*TrustedBeneficiary),
+	}
+}
+
+// SaveSCADecision grava uma cópia da decisão
+func (s *InMemorySCAExemptionStore) SaveSCADecision(ctx context.Context, decision *SCAExemptionDecision) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *decision
+	s.decisions[decision.TenantID+"/"+decision.TransactionID] = &copied
+	return nil
+}
+
+// GetSCADecision retorna uma cópia da decisão do pagamento
+func (s *InMemorySCAExemptionStore) GetSCADecision(ctx context.Context, tenantID, transactionID string) (*SCAExemptionDecision, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	decision, ok := s.decisions[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrSCADecisionNotFound
+	}
+	copied := *decision
+	return &copied, nil
+}
+
+// ListSCADecisions retorna cópias das decisões do filtro por ordem de decisão
+func (s *InMemorySCAExemptionStore) ListSCADecisions(ctx context.Context, filter SCADecisionFilter) ([]*SCAExemptionDecision, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	decisions := make([]*SCAExemptionDecision, 0)
+	for _, decision := range s.decisions {
+		if filter.TenantID != "" && decision.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.MerchantID != "" && decision.MerchantID != filter.MerchantID {
+			continue
+		}
+		if (!filter.From.IsZero() && decision.DecidedAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !decision.DecidedAt.Before(filter.To)) {
+			continue
+		}
+		copied := *decision
+		decisions = append(decisions, &copied)
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].DecidedAt.Before(decisions[j].DecidedAt) })
+	return decisions, nil
+}
+
+// SCAVolume soma os pagamentos concluídos do instrumento no período
+func (s *InMemorySCAExemptionStore) SCAVolume(ctx context.Context, tenantID, instrument string, since time.Time) (float64, float64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	total, fraud := 0.0, 0.0
+	for _, decision := range s.decisions {
+		if decision.TenantID != tenantID || decision.Instrument != instrument || !decision.Completed ||
+			decision.DecidedAt.Before(since) {
+			continue
+		}
+		total += decision.Amount
+		if decision.Fraudulent {
+			fraud += decision.Amount
+		}
+	}
+	return total, fraud, nil
+}
+
+// DeleteSCADecisionsBefore remove as decisões anteriores a cutoff
+func (s *InMemorySCAExemptionStore) DeleteSCADecisionsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removed := 0
+	for key, decision := range s.decisions {
+		if decision.DecidedAt.Before(cutoff) {
+			delete(s.decisions, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// ReserveSCALowValue soma o pagamento ao contador do instrumento dentro dos limites de baixo valor
+func (s *InMemorySCAExemptionStore) ReserveSCALowValue(ctx context.Context, tenantID, instrumentKey string, amount float64) (SCALowValueCounter, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := tenantID + "/" + instrumentKey
+	counter := s.lowValue[key]
+	if counter.Count >= SCALowValueMaxCount || counter.Cumulative+amount > SCALowValueMaxCumulative {
+		return counter, false, nil
+	}
+	counter.Count++
+	counter.Cumulative = roundAmount(counter.Cumulative + amount)
+	s.lowValue[key] = counter
+	return counter, true, nil
+}
+
+// ResetSCALowValue reinicia o contador do instrumento
+func (s *InMemorySCAExemptionStore) ResetSCALowValue(ctx context.Context, tenantID, instrumentKey string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.lowValue, tenantID+"/"+instrumentKey)
+	return nil
+}
+
+// SaveTrustedBeneficiary grava uma cópia do beneficiário
+func (s *InMemorySCAExemptionStore) SaveTrustedBeneficiary(ctx context.Context, beneficiary *TrustedBeneficiary) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *beneficiary
+	s.beneficiaries[beneficiary.TenantID+"/"+beneficiary.UserID+"/"+beneficiary.Key] = &copied
+	return nil
+}
+
+// IsTrustedBeneficiary indica se o beneficiário consta da lista de confiança
+func (s *InMemorySCAExemptionStore) IsTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	_, ok := s.beneficiaries[tenantID+"/"+userID+"/"+key]
+	return ok, nil
+}
+
+// ListTrustedBeneficiaries retorna cópias da lista de confiança do pagador ordenadas por chave
+func (s *InMemorySCAExemptionStore) ListTrustedBeneficiaries(ctx context.Context, tenantID, userID string) ([]*TrustedBeneficiary, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	beneficiaries := make([]*TrustedBeneficiary, 0)
+	for _, beneficiary := range s.beneficiaries {
+		if beneficiary.TenantID == tenantID && beneficiary.UserID == userID {
+			copied := *beneficiary
+			beneficiaries = append(beneficiaries, &copied)
+		}
+	}
+	sort.Slice(beneficiaries, func(i, j int) bool { return beneficiaries[i].Key < beneficiaries[j].Key })
+	return beneficiaries, nil
+}
+
+// DeleteTrustedBeneficiary retira o beneficiário da lista de confiança
+func (s *InMemorySCAExemptionStore) DeleteTrustedBeneficiary(ctx context.Context, tenantID, userID, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := tenantID + "/" + userID + "/" + key
+	if _, ok := s.beneficiaries[id]; !ok {
+		return ErrSCATrustedBeneficiaryNotFound
+	}
+	delete(s.beneficiaries, id)
+	return nil
+}
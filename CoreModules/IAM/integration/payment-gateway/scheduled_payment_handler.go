@@ -0,0 +1,133 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ScheduledPaymentHandler expõe às equipas de suporte os pagamentos agendados e o calendário bancário dos mercados
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type ScheduledPaymentHandler struct {
+	service *ScheduledPaymentService
+}
+
+// NewScheduledPaymentHandler cria uma nova instância do ScheduledPaymentHandler
+func NewScheduledPaymentHandler(service *ScheduledPaymentService) *ScheduledPaymentHandler {
+	return &ScheduledPaymentHandler{service: service}
+}
+
+// scheduledPaymentRequest é o corpo do agendamento: o pagamento e a data pedida ("AAAA-MM-DD")
+type scheduledPaymentRequest struct {
+	ExecutionDate string         `json:"execution_date"`
+	Payment       PaymentRequest `json:"payment"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *ScheduledPaymentHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/scheduled-payments", h.List).Methods(http.MethodGet)
+	router.HandleFunc("/support/scheduled-payments", h.Schedule).Methods(http.MethodPost)
+	router.HandleFunc("/support/scheduled-payments/calendar", h.Calendar).Methods(http.MethodGet)
+	router.HandleFunc("/support/scheduled-payments/{paymentId}", h.Get).Methods(http.MethodGet)
+	router.HandleFunc("/support/scheduled-payments/{paymentId}/cancel", h.Cancel).Methods(http.MethodPost)
+}
+
+// List lista os pagamentos agendados do tenant filtrados por usuário e status
+func (h *ScheduledPaymentHandler) List(w http.ResponseWriter, r *http.Request) {
+	payments, err := h.service.List(r.Context(), ScheduledPaymentFilter{
+		TenantID: r.Header.Get("X-Tenant-ID"),
+		UserID:   r.URL.Query().Get("user_id"),
+		Status:   r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar pagamentos agendados")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payments)
+}
+
+// Schedule pré-autoriza e agenda um pagamento
+func (h *ScheduledPaymentHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	var req scheduledPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	date, err := time.Parse(bankHolidayDateLayout, req.ExecutionDate)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_date", "execution_date inválida (esperado AAAA-MM-DD)")
+		return
+	}
+	req.Payment.TenantID = r.Header.Get("X-Tenant-ID")
+
+	payment, err := h.service.Schedule(r.Context(), &req.Payment, date)
+	if err != nil {
+		h.respondWithScheduledPaymentError(w, err, "Erro ao agendar pagamento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, payment)
+}
+
+// Calendar indica se a data é útil no mercado e o dia em que um pagamento agendado para ela é executado
+func (h *ScheduledPaymentHandler) Calendar(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse(bankHolidayDateLayout, r.URL.Query().Get("date"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_date", "date inválida (esperado AAAA-MM-DD)")
+		return
+	}
+
+	market := r.URL.Query().Get("market")
+	if market == "" {
+		market = RegionGlobal
+	}
+	respondWithJSON(w, http.StatusOK, h.service.BusinessDay(market, date))
+}
+
+// Get retorna um pagamento agendado do tenant
+func (h *ScheduledPaymentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	payment, err := h.service.Get(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["paymentId"])
+	if err != nil {
+		h.respondWithScheduledPaymentError(w, err, "Erro ao recuperar pagamento agendado")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payment)
+}
+
+// Cancel cancela um pagamento agendado em nome do operador autenticado
+func (h *ScheduledPaymentHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	payment, err := h.service.Cancel(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["paymentId"], supportOperator(r))
+	if err != nil {
+		h.respondWithScheduledPaymentError(w, err, "Erro ao cancelar pagamento agendado")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, payment)
+}
+
+// respondWithScheduledPaymentError traduz os erros do serviço de pagamentos agendados em respostas HTTP
+func (h *ScheduledPaymentHandler) respondWithScheduledPaymentError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrScheduledPaymentNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, ErrScheduledPaymentNotScheduled):
+		respondWithError(w, http.StatusConflict, "not_scheduled", err.Error())
+	case errors.Is(err, ErrScheduledPaymentCancellationClosed):
+		respondWithError(w, http.StatusConflict, "cancellation_closed", err.Error())
+	case errors.Is(err, ErrPaymentConsentUnavailable):
+		respondWithError(w, http.StatusServiceUnavailable, "consent_unavailable", err.Error())
+	case errors.Is(err, ErrScheduledPaymentRejected):
+		respondWithError(w, http.StatusUnprocessableEntity, "preauthorization_rejected", err.Error())
+	case errors.Is(err, ErrScheduledPaymentInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrScheduledPaymentDateInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_date", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
@@ -0,0 +1,112 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estados de um pagamento agendado
+const (
+	ScheduledPaymentStatusScheduled = "scheduled"
+	ScheduledPaymentStatusExecuting = "executing" // Reservado para execução na data agendada
+	ScheduledPaymentStatusExecuted  = "executed"
+	ScheduledPaymentStatusFailed    = "failed" // Recusado na revalidação ou no processamento na data de execução
+	ScheduledPaymentStatusCancelled = "cancelled"
+)
+
+// Verificações de pré-autorização dos pagamentos agendados, repetidas na data de execução
+const (
+	ScheduledPaymentCheckLimits    = "limits"
+	ScheduledPaymentCheckSanctions = "sanctions"
+	ScheduledPaymentCheckConsent   = "consent"
+)
+
+// Valores padrão dos pagamentos agendados
+const (
+	DefaultScheduledPaymentExecutionInterval = 15 * time.Minute // Periodicidade da execução dos pagamentos do dia
+	DefaultScheduledPaymentMaxDays           = 365              // Antecedência máxima do agendamento
+	DefaultScheduledPaymentCancellationDays  = 1                // Cancelamento até ao fim do dia útil anterior à execução
+	DefaultScheduledPaymentConsentTimeout    = 5 * time.Second
+
+	// ScheduledPaymentConsentPurpose é a finalidade do consentimento do cliente para pagamentos agendados
+	ScheduledPaymentConsentPurpose = "payment:scheduled"
+)
+
+// scheduledPaymentChecks é a ordem das verificações de pré-autorização
+var scheduledPaymentChecks = []string{
+	ScheduledPaymentCheckLimits,
+	ScheduledPaymentCheckSanctions,
+	ScheduledPaymentCheckConsent,
+}
+
+// Erros dos pagamentos agendados
+var (
+	ErrScheduledPaymentNotFound           = errors.New("pagamento agendado não encontrado")
+	ErrScheduledPaymentInvalid            = errors.New("pedido de pagamento agendado inválido")
+	ErrScheduledPaymentDateInvalid        = errors.New("data de execução fora do período de agendamento")
+	ErrScheduledPaymentNotScheduled       = errors.New("pagamento agendado já executado ou cancelado")
+	ErrScheduledPaymentCancellationClosed = errors.New("prazo de cancelamento do pagamento agendado encerrado")
+	ErrScheduledPaymentRejected           = errors.New("pagamento agendado recusado na pré-autorização")
+	ErrPaymentConsentUnavailable          = errors.New("serviço de consentimentos indisponível")
+)
+
+// ScheduledPaymentConfig contém configurações dos pagamentos agendados
+type ScheduledPaymentConfig struct {
+	MaxDays           int           `json:"max_days"`           // Antecedência máxima em dias (padrão 365)
+	CancellationDays  int           `json:"cancellation_days"`  // Dias úteis antes da execução em que termina o cancelamento (padrão 1)
+	ExecutionInterval time.Duration `json:"execution_interval"` // Periodicidade da execução dos pagamentos do dia
+
+	// Feriados bancários adicionais por mercado ("AAAA-MM-DD"), além de DefaultBankHolidayCalendars
+	MarketBankHolidays map[string][]string `json:"market_bank_holidays"`
+
+	// Nomes da lista SDN da OFAC usados na triagem dos beneficiários
+	OFACSanctionedNames []string `json:"ofac_sanctioned_names"`
+
+	// Serviço de consentimentos do IAM que confirma o consentimento do cliente
+	ConsentServiceURL string            `json:"consent_service_url"`
+	APIKey            string            `json:"-"`
+	HTTPTimeout       time.Duration     `json:"http_timeout"`
+	Resilience        resilience.Policy `json:"resilience"`
+}
+
+// ScheduledPayment é um pagamento único agendado pelo cliente para uma data futura
+type ScheduledPayment struct {
+	ID               string          `json:"id" db:"id"`
+	TenantID         string          `json:"tenant_id" db:"tenant_id"`
+	TransactionID    string          `json:"transaction_id" db:"transaction_id"`
+	MerchantID       string          `json:"merchant_id" db:"merchant_id"`
+	UserID           string          `json:"user_id" db:"user_id"`
+	PaymentMethod    string          `json:"payment_method" db:"payment_method"`
+	Amount           float64         `json:"amount" db:"amount"`
+	Currency         string          `json:"currency" db:"currency"`
+	Market           string          `json:"market" db:"market"`
+	RequestedDate    time.Time       `json:"requested_date" db:"requested_date"`       // Data pedida pelo cliente
+	ExecutionDate    time.Time       `json:"execution_date" db:"execution_date"`       // Dia útil de execução no calendário do mercado
+	CancellableUntil time.Time       `json:"cancellable_until" db:"cancellable_until"` // Fim do prazo de cancelamento pelo cliente
+	Status           string          `json:"status" db:"status"`
+	Reference        string          `json:"reference,omitempty" db:"reference"` // Autorização do pagamento executado
+	FailureReason    string          `json:"failure_reason,omitempty" db:"failure_reason"`
+	CancelledBy      string          `json:"cancelled_by,omitempty" db:"cancelled_by"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	ExecutedAt       *time.Time      `json:"executed_at,omitempty" db:"executed_at"`
+	CancelledAt      *time.Time      `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	Request          *PaymentRequest `json:"-" db:"-"` // Pedido submetido ao processamento na data de execução
+}
+
+// ScheduledPaymentFilter seleciona os pagamentos agendados listados
+type ScheduledPaymentFilter struct {
+	TenantID string
+	UserID   string
+	Status   string
+}
+
+// BusinessDay indica se uma data é útil no calendário bancário do mercado
+type BusinessDay struct {
+	Market        string    `json:"market"`
+	Date          time.Time `json:"date"`
+	BusinessDay   bool      `json:"business_day"`
+	Holiday       bool      `json:"holiday"`
+	ExecutionDate time.Time `json:"execution_date"` // Dia útil em que um pagamento agendado para a data é executado
+}
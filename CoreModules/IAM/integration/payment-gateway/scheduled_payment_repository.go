@@ -0,0 +1,167 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresScheduledPaymentStore implementa ScheduledPaymentStore para PostgreSQL
+type PostgresScheduledPaymentStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresScheduledPaymentStore cria uma nova instância de PostgresScheduledPaymentStore
+func NewPostgresScheduledPaymentStore(db *sqlx.DB) *PostgresScheduledPaymentStore {
+	return &PostgresScheduledPaymentStore{db: db}
+}
+
+// dbScheduledPayment é a representação de ScheduledPayment na base de dados
+type dbScheduledPayment struct {
+	ScheduledPayment
+	RequestJSON []byte `db:"request"`
+	FromStatus  string `db:"from_status"`
+}
+
+// scheduledPaymentColumns são as colunas lidas nas consultas de pagamentos agendados
+const scheduledPaymentColumns = `id, tenant_id, transaction_id, merchant_id, user_id, payment_method, amount, currency,
+	market, requested_date, execution_date, cancellable_until, status, reference, failure_reason, cancelled_by,
+	created_at, executed_at, cancelled_at, request`
+
+// CreateScheduledPayment grava um novo pagamento agendado
+func (r *PostgresScheduledPaymentStore) CreateScheduledPayment(ctx context.Context, payment *ScheduledPayment) error {
+	row := &dbScheduledPayment{ScheduledPayment: *payment}
+	var err error
+	if row.RequestJSON, err = json.Marshal(payment.Request); err != nil {
+		return fmt.Errorf("falha ao codificar pedido do pagamento agendado: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.scheduled_payments (
+			id, tenant_id, transaction_id, merchant_id, user_id, payment_method, amount, currency,
+			market, requested_date, execution_date, cancellable_until, status, reference, failure_reason, cancelled_by,
+			created_at, executed_at, cancelled_at, request
+		) VALUES (
+			:id, :tenant_id, :transaction_id, :merchant_id, :user_id, :payment_method, :amount, :currency,
+			:market, :requested_date, :execution_date, :cancellable_until, :status, :reference, :failure_reason, :cancelled_by,
+			:created_at, :executed_at, :cancelled_at, :request
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar pagamento agendado: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateScheduledPayment grava o desfecho do pagamento se o estado atual for fromStatus
+func (r *PostgresScheduledPaymentStore) UpdateScheduledPayment(ctx context.Context, payment *ScheduledPayment, fromStatus string) error {
+	row := &dbScheduledPayment{ScheduledPayment: *payment, FromStatus: fromStatus}
+
+	query := `
+		UPDATE payment_gateway.scheduled_payments SET
+			status = :status,
+			reference = :reference,
+			failure_reason = :failure_reason,
+			cancelled_by = :cancelled_by,
+			executed_at = :executed_at,
+			cancelled_at = :cancelled_at
+		WHERE tenant_id = :tenant_id AND id = :id AND status = :from_status
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, row)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar pagamento agendado: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+	if affected == 0 {
+		return ErrScheduledPaymentNotScheduled
+	}
+
+	return nil
+}
+
+// GetScheduledPayment recupera o pagamento do tenant
+func (r *PostgresScheduledPaymentStore) GetScheduledPayment(ctx context.Context, tenantID, paymentID string) (*ScheduledPayment, error) {
+	var row dbScheduledPayment
+	query := `SELECT ` + scheduledPaymentColumns + ` FROM payment_gateway.scheduled_payments
+		WHERE tenant_id = $1 AND id = $2`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, paymentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduledPaymentNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar pagamento agendado: %w", err)
+	}
+	return row.decode()
+}
+
+// ListScheduledPayments lista os pagamentos do filtro por data de execução e ordem de agendamento
+func (r *PostgresScheduledPaymentStore) ListScheduledPayments(ctx context.Context, filter ScheduledPaymentFilter) ([]*ScheduledPayment, error) {
+	var rows []dbScheduledPayment
+	query := `SELECT ` + scheduledPaymentColumns + ` FROM payment_gateway.scheduled_payments
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR user_id = $2) AND ($3 = '' OR status = $3)
+		ORDER BY execution_date, created_at`
+	if err := r.db.SelectContext(ctx, &rows, query, filter.TenantID, filter.UserID, filter.Status); err != nil {
+		return nil, fmt.Errorf("falha ao listar pagamentos agendados: %w", err)
+	}
+	return decodeScheduledPayments(rows)
+}
+
+// ClaimDueScheduledPayments marca como em execução os pagamentos vencidos numa única instrução,
+// com SKIP LOCKED para que réplicas concorrentes reservem pagamentos distintos
+func (r *PostgresScheduledPaymentStore) ClaimDueScheduledPayments(ctx context.Context, now time.Time) ([]*ScheduledPayment, error) {
+	var rows []dbScheduledPayment
+	query := `
+		UPDATE payment_gateway.scheduled_payments SET status = 'executing'
+		WHERE (tenant_id, id) IN (
+			SELECT tenant_id, id FROM payment_gateway.scheduled_payments
+			WHERE status = 'scheduled' AND execution_date <= $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledPaymentColumns
+	if err := r.db.SelectContext(ctx, &rows, query, now); err != nil {
+		return nil, fmt.Errorf("falha ao reservar pagamentos agendados: %w", err)
+	}
+
+	payments, err := decodeScheduledPayments(rows)
+	if err != nil {
+		return nil, err
+	}
+	sortScheduledPayments(payments)
+	return payments, nil
+}
+
+// decodeScheduledPayments descodifica os pedidos gravados em JSONB
+func decodeScheduledPayments(rows []dbScheduledPayment) ([]*ScheduledPayment, error) {
+	payments := make([]*ScheduledPayment, 0, len(rows))
+	for i := range rows {
+		payment, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
+
+// decode descodifica o pedido a executar
+func (row *dbScheduledPayment) decode() (*ScheduledPayment, error) {
+	payment := row.ScheduledPayment
+	if len(row.RequestJSON) > 0 {
+		var request PaymentRequest
+		if err := json.Unmarshal(row.RequestJSON, &request); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar pedido do pagamento agendado: %w", err)
+		}
+		payment.Request = &request
+	}
+	return &payment, nil
+}
@@ -0,0 +1,449 @@
+package paymentgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// ScheduledPaymentProcessor verifica os limites e processa os pagamentos agendados; o
+// BureauPaymentGatewayConnector aplica as mesmas regras dos pagamentos imediatos
+type ScheduledPaymentProcessor interface {
+	CheckLimits(ctx context.Context, req *PaymentRequest) (*LimitCheckResult, error)
+	ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error)
+}
+
+// ScheduledPaymentCheck verifica um pagamento no agendamento e, de novo, na data de execução
+type ScheduledPaymentCheck func(ctx context.Context, req *PaymentRequest) error
+
+// ScheduledPaymentService agenda pagamentos únicos para um dia útil futuro do mercado. Os limites, as
+// sanções e o consentimento são verificados no agendamento e revalidados na data de execução, e o
+// cliente pode cancelar o pagamento até ao fim do prazo de cancelamento
+type ScheduledPaymentService struct {
+	config    ScheduledPaymentConfig
+	store     ScheduledPaymentStore
+	processor ScheduledPaymentProcessor
+	consent   PaymentConsentClient
+	webhooks  *WebhookDeliveryService
+	calendars map[string]BankHolidayCalendar
+	checks    map[string]ScheduledPaymentCheck
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewScheduledPaymentService cria o serviço de pagamentos agendados. Sem cliente de consentimentos é
+// usada a API HTTP de consentimentos do IAM
+func NewScheduledPaymentService(config ScheduledPaymentConfig, store ScheduledPaymentStore, processor ScheduledPaymentProcessor, consent PaymentConsentClient) (*ScheduledPaymentService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-scheduled-payments",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.MaxDays <= 0 {
+		config.MaxDays = DefaultScheduledPaymentMaxDays
+	}
+	if config.CancellationDays <= 0 {
+		config.CancellationDays = DefaultScheduledPaymentCancellationDays
+	}
+	if config.ExecutionInterval <= 0 {
+		config.ExecutionInterval = DefaultScheduledPaymentExecutionInterval
+	}
+	if consent == nil {
+		consent = NewHTTPPaymentConsentClient(config)
+	}
+
+	calendars := DefaultBankHolidayCalendars()
+	for market, dates := range config.MarketBankHolidays {
+		for _, date := range dates {
+			if _, err := time.Parse(bankHolidayDateLayout, date); err != nil {
+				return nil, fmt.Errorf("feriado bancário inválido no mercado %s: %w", market, err)
+			}
+		}
+		calendar := calendars[market]
+		calendar.Market = market
+		calendar.Dates = append(append([]string(nil), calendar.Dates...), dates...)
+		calendars[market] = calendar
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &ScheduledPaymentService{
+		config:          config,
+		store:           store,
+		processor:       processor,
+		consent:         consent,
+		calendars:       calendars,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+	service.checks = map[string]ScheduledPaymentCheck{
+		ScheduledPaymentCheckLimits:    service.checkLimits,
+		ScheduledPaymentCheckSanctions: newScheduledPaymentSanctionsCheck(config.OFACSanctionedNames),
+		ScheduledPaymentCheckConsent:   service.checkConsent,
+	}
+	return service, nil
+}
+
+// SetWebhookDeliveryService ativa a notificação aos comerciantes dos pagamentos recusados na revalidação;
+// os pagamentos processados são notificados pelo processamento
+func (s *ScheduledPaymentService) SetWebhookDeliveryService(webhooks *WebhookDeliveryService) {
+	s.webhooks = webhooks
+}
+
+// Start inicia a execução periódica dos pagamentos cuja data de execução chegou
+func (s *ScheduledPaymentService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.ExecutionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.ExecuteDue(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Execução de pagamentos agendados iniciada", "interval", s.config.ExecutionInterval.String())
+}
+
+// Stop interrompe a execução periódica
+func (s *ScheduledPaymentService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// checkLimits recusa os pagamentos acima dos limites de transação do processamento
+func (s *ScheduledPaymentService) checkLimits(ctx context.Context, req *PaymentRequest) error {
+	result, err := s.processor.CheckLimits(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return fmt.Errorf("%s: %s", result.Reason, result.Details)
+	}
+	return nil
+}
+
+// checkConsent exige o consentimento ativo do cliente para pagamentos agendados
+func (s *ScheduledPaymentService) checkConsent(ctx context.Context, req *PaymentRequest) error {
+	granted, err := s.consent.HasConsent(ctx, req.TenantID, req.UserID, ScheduledPaymentConsentPurpose)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return errors.New("consentimento do cliente para pagamentos agendados não encontrado")
+	}
+	return nil
+}
+
+// newScheduledPaymentSanctionsCheck cria a triagem OFAC do beneficiário, indicado em
+// PaymentDetails["beneficiary_name"] e PaymentDetails["beneficiary_country"], e do país de cobrança
+func newScheduledPaymentSanctionsCheck(sanctionedNames []string) ScheduledPaymentCheck {
+	names := make(map[string]bool, len(sanctionedNames))
+	for _, name := range sanctionedNames {
+		if normalized := normalizeScreeningName(name); normalized != "" {
+			names[normalized] = true
+		}
+	}
+
+	return func(ctx context.Context, req *PaymentRequest) error {
+		beneficiaryName, _ := req.PaymentDetails["beneficiary_name"].(string)
+		beneficiaryCountry, _ := req.PaymentDetails["beneficiary_country"].(string)
+		countries := []string{beneficiaryCountry}
+		if req.BillingAddress != nil {
+			countries = append(countries, req.BillingAddress.Country)
+		}
+		for _, country := range countries {
+			if ofacSanctionedCountries[strings.ToUpper(country)] {
+				return fmt.Errorf("país %s sob sanções OFAC", country)
+			}
+		}
+		if names[normalizeScreeningName(beneficiaryName)] {
+			return errors.New("beneficiário consta da lista SDN da OFAC")
+		}
+		return nil
+	}
+}
+
+// Calendar retorna o calendário de feriados bancários do mercado ou, sem calendário próprio, o global
+func (s *ScheduledPaymentService) Calendar(market string) BankHolidayCalendar {
+	if calendar, exists := s.calendars[market]; exists {
+		return calendar
+	}
+	return s.calendars[RegionGlobal]
+}
+
+// BusinessDay indica se a data é útil no mercado e o dia em que um pagamento agendado para ela é executado
+func (s *ScheduledPaymentService) BusinessDay(market string, date time.Time) BusinessDay {
+	calendar := s.Calendar(market)
+	return BusinessDay{
+		Market:        market,
+		Date:          calendarDay(date),
+		BusinessDay:   calendar.IsBusinessDay(date),
+		Holiday:       calendar.IsHoliday(date),
+		ExecutionDate: calendar.NextBusinessDay(date),
+	}
+}
+
+// Validate executa as verificações de pré-autorização pela ordem de scheduledPaymentChecks
+// As recusas envolvem ErrScheduledPaymentRejected e indicam a verificação que falhou
+func (s *ScheduledPaymentService) Validate(ctx context.Context, req *PaymentRequest) error {
+	for _, name := range scheduledPaymentChecks {
+		check, exists := s.checks[name]
+		if !exists {
+			continue
+		}
+		if err := check(ctx, req); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrScheduledPaymentRejected, name, err)
+		}
+	}
+	return nil
+}
+
+// Schedule pré-autoriza o pagamento e agenda-o para o dia pedido ou, quando não é útil no mercado, para
+// o dia útil seguinte. O dia pedido deve ser posterior a hoje e estar dentro da antecedência máxima
+func (s *ScheduledPaymentService) Schedule(ctx context.Context, req *PaymentRequest, date time.Time) (*ScheduledPayment, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "ScheduledPaymentService.Schedule")
+	defer span.End()
+
+	if req.TenantID == "" || req.UserID == "" || req.MerchantID == "" || req.PaymentMethod == "" ||
+		req.Currency == "" || req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: tenant, usuário, comerciante, meio de pagamento, moeda e valor positivo obrigatórios",
+			ErrScheduledPaymentInvalid)
+	}
+
+	now := s.now()
+	today := calendarDay(now)
+	requested := calendarDay(date)
+	last := today.AddDate(0, 0, s.config.MaxDays)
+	if !requested.After(today) || requested.After(last) {
+		return nil, fmt.Errorf("%w: %s (permitido de %s a %s)", ErrScheduledPaymentDateInvalid,
+			requested.Format(bankHolidayDateLayout), today.AddDate(0, 0, 1).Format(bankHolidayDateLayout),
+			last.Format(bankHolidayDateLayout))
+	}
+
+	if err := s.Validate(ctx, req); err != nil {
+		span.RecordError(err)
+		s.metricsRecorder.CounterInc("payment_gateway_scheduled_payments", map[string]string{
+			"market": req.RegionCode,
+			"status": "rejected",
+		})
+		// Evento de segurança: o agendamento foi recusado na pré-autorização
+		s.logger.WarnWithContext(ctx, "Agendamento de pagamento recusado na pré-autorização",
+			"tenant_id", req.TenantID,
+			"user_id", req.UserID,
+			"transaction_id", req.TransactionID,
+			"error", err.Error())
+		return nil, err
+	}
+
+	// O prazo de cancelamento termina no fim do dia útil que antecede a execução pelo número de dias configurado
+	calendar := s.Calendar(req.RegionCode)
+	execution := calendar.NextBusinessDay(requested)
+	cutoff := execution
+	for i := 0; i < s.config.CancellationDays; i++ {
+		cutoff = calendar.PreviousBusinessDay(cutoff)
+	}
+
+	request := *req
+	if request.TransactionID == "" {
+		request.TransactionID = uuid.New().String()
+	}
+	payment := &ScheduledPayment{
+		ID:               uuid.New().String(),
+		TenantID:         request.TenantID,
+		TransactionID:    request.TransactionID,
+		MerchantID:       request.MerchantID,
+		UserID:           request.UserID,
+		PaymentMethod:    request.PaymentMethod,
+		Amount:           roundAmount(request.Amount),
+		Currency:         request.Currency,
+		Market:           request.RegionCode,
+		RequestedDate:    requested,
+		ExecutionDate:    execution,
+		CancellableUntil: cutoff.AddDate(0, 0, 1),
+		Status:           ScheduledPaymentStatusScheduled,
+		CreatedAt:        now.UTC(),
+	}
+	request.Metadata = make(map[string]interface{}, len(req.Metadata)+2)
+	for key, value := range req.Metadata {
+		request.Metadata[key] = value
+	}
+	request.Metadata["scheduled_payment_id"] = payment.ID
+	request.Metadata["scheduled_execution_date"] = execution.Format(bankHolidayDateLayout)
+	payment.Request = &request
+
+	if err := s.store.CreateScheduledPayment(ctx, payment); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_scheduled_payments", map[string]string{
+		"market": payment.Market,
+		"status": ScheduledPaymentStatusScheduled,
+	})
+	s.logger.InfoWithContext(ctx, "Pagamento agendado",
+		"tenant_id", payment.TenantID,
+		"scheduled_payment_id", payment.ID,
+		"transaction_id", payment.TransactionID,
+		"requested_date", requested.Format(bankHolidayDateLayout),
+		"execution_date", execution.Format(bankHolidayDateLayout),
+		"cancellable_until", payment.CancellableUntil.Format(time.RFC3339))
+	return payment, nil
+}
+
+// Cancel cancela o pagamento a pedido do cliente até ao fim do prazo de cancelamento
+func (s *ScheduledPaymentService) Cancel(ctx context.Context, tenantID, paymentID, cancelledBy string) (*ScheduledPayment, error) {
+	payment, err := s.store.GetScheduledPayment(ctx, tenantID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != ScheduledPaymentStatusScheduled {
+		return nil, fmt.Errorf("%w: %s", ErrScheduledPaymentNotScheduled, payment.Status)
+	}
+	now := s.now()
+	if !now.Before(payment.CancellableUntil) {
+		return nil, fmt.Errorf("%w em %s", ErrScheduledPaymentCancellationClosed, payment.CancellableUntil.Format(time.RFC3339))
+	}
+
+	cancelledAt := now.UTC()
+	payment.Status = ScheduledPaymentStatusCancelled
+	payment.CancelledAt = &cancelledAt
+	payment.CancelledBy = cancelledBy
+	if err := s.store.UpdateScheduledPayment(ctx, payment, ScheduledPaymentStatusScheduled); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_scheduled_payments", map[string]string{
+		"market": payment.Market,
+		"status": ScheduledPaymentStatusCancelled,
+	})
+	s.logger.InfoWithContext(ctx, "Pagamento agendado cancelado",
+		"tenant_id", tenantID,
+		"scheduled_payment_id", paymentID,
+		"cancelled_by", cancelledBy)
+	return payment, nil
+}
+
+// Get retorna o pagamento agendado do tenant
+func (s *ScheduledPaymentService) Get(ctx context.Context, tenantID, paymentID string) (*ScheduledPayment, error) {
+	return s.store.GetScheduledPayment(ctx, tenantID, paymentID)
+}
+
+// List retorna os pagamentos do filtro por data de execução
+func (s *ScheduledPaymentService) List(ctx context.Context, filter ScheduledPaymentFilter) ([]*ScheduledPayment, error) {
+	return s.store.ListScheduledPayments(ctx, filter)
+}
+
+// ExecuteDue reserva e executa os pagamentos cuja data de execução chegou, retornando o desfecho de cada um
+func (s *ScheduledPaymentService) ExecuteDue(ctx context.Context) []*ScheduledPayment {
+	due, err := s.store.ClaimDueScheduledPayments(ctx, s.now())
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao reservar pagamentos agendados", "error", err.Error())
+		return nil
+	}
+
+	executed := make([]*ScheduledPayment, 0, len(due))
+	for _, payment := range due {
+		executed = append(executed, s.execute(ctx, payment))
+	}
+	return executed
+}
+
+// execute revalida o pagamento reservado, já que os limites, as sanções e o consentimento podem ter
+// mudado desde o agendamento, submete-o ao processamento e grava o desfecho
+func (s *ScheduledPaymentService) execute(ctx context.Context, payment *ScheduledPayment) *ScheduledPayment {
+	ctx, span := s.tracer.StartSpan(ctx, "ScheduledPaymentService.execute")
+	defer span.End()
+
+	err := s.run(ctx, payment)
+	executedAt := s.now().UTC()
+	payment.ExecutedAt = &executedAt
+	payment.Status = ScheduledPaymentStatusExecuted
+	if err != nil {
+		span.RecordError(err)
+		payment.Status = ScheduledPaymentStatusFailed
+		payment.FailureReason = err.Error()
+	}
+	if updateErr := s.store.UpdateScheduledPayment(ctx, payment, ScheduledPaymentStatusExecuting); updateErr != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar execução do pagamento agendado",
+			"scheduled_payment_id", payment.ID,
+			"error", updateErr.Error())
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_scheduled_payments", map[string]string{
+		"market": payment.Market,
+		"status": payment.Status,
+	})
+	s.logger.InfoWithContext(ctx, "Pagamento agendado processado",
+		"tenant_id", payment.TenantID,
+		"scheduled_payment_id", payment.ID,
+		"transaction_id", payment.TransactionID,
+		"status", payment.Status,
+		"reference", payment.Reference)
+	return payment
+}
+
+// run revalida e processa o pedido gravado, registando a autorização do pagamento aprovado
+func (s *ScheduledPaymentService) run(ctx context.Context, payment *ScheduledPayment) error {
+	req := payment.Request
+	if req == nil {
+		return fmt.Errorf("%w: pedido a executar não gravado", ErrScheduledPaymentInvalid)
+	}
+	req.Timestamp = s.now().UTC()
+
+	if err := s.Validate(ctx, req); err != nil {
+		// Evento de segurança: recusado antes do processamento, que notificaria o comerciante
+		s.logger.WarnWithContext(ctx, "Pagamento agendado recusado na data de execução",
+			"tenant_id", payment.TenantID,
+			"scheduled_payment_id", payment.ID,
+			"transaction_id", payment.TransactionID,
+			"error", err.Error())
+		if s.webhooks != nil {
+			s.webhooks.NotifyTransaction(ctx, req, &PaymentResponse{
+				TransactionID:     req.TransactionID,
+				Status:            TransactionStatusDenied,
+				StatusDescription: err.Error(),
+			})
+		}
+		return err
+	}
+
+	response, err := s.processor.ProcessPayment(ctx, req)
+	if err != nil {
+		return err
+	}
+	if response.Status != TransactionStatusApproved {
+		return fmt.Errorf("pagamento %s: %s", response.Status, response.StatusDescription)
+	}
+	payment.Reference = response.AuthorizationID
+	return nil
+}
@@ -0,0 +1,320 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScheduledPaymentProcessor aprova os pagamentos até ao limite e regista os processados
+type fakeScheduledPaymentProcessor struct {
+	mu        sync.Mutex
+	limit     float64
+	processed []string
+}
+
+func (p *fakeScheduledPaymentProcessor) CheckLimits(ctx context.Context, req *PaymentRequest) (*LimitCheckResult, error) {
+	if req.Amount > p.limit {
+		return &LimitCheckResult{Allowed: false, Reason: "Limite por transação excedido", Details: "limite 1000"}, nil
+	}
+	return &LimitCheckResult{Allowed: true}, nil
+}
+
+func (p *fakeScheduledPaymentProcessor) ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed = append(p.processed, req.TransactionID)
+	return &PaymentResponse{
+		TransactionID:   req.TransactionID,
+		Status:          TransactionStatusApproved,
+		AuthorizationID: "AUTH-" + req.TransactionID,
+	}, nil
+}
+
+func (p *fakeScheduledPaymentProcessor) calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.processed...)
+}
+
+// fakePaymentConsentClient concede o consentimento aos usuários sem entrada em revoked
+type fakePaymentConsentClient struct {
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (c *fakePaymentConsentClient) HasConsent(ctx context.Context, tenantID, userID, purpose string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.revoked[userID], nil
+}
+
+func (c *fakePaymentConsentClient) revoke(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revoked[userID] = true
+}
+
+func newTestScheduledPaymentService(t *testing.T) (*ScheduledPaymentService, *fakeScheduledPaymentProcessor, *fakePaymentConsentClient, *time.Time) {
+	t.Helper()
+
+	processor := &fakeScheduledPaymentProcessor{limit: 1000}
+	consent := &fakePaymentConsentClient{revoked: map[string]bool{}}
+	service, err := NewScheduledPaymentService(ScheduledPaymentConfig{
+		MarketBankHolidays:  map[string][]string{RegionUSA: {"2026-11-26"}}, // Thanksgiving
+		OFACSanctionedNames: []string{"Sanctioned Trading Co"},
+	}, NewInMemoryScheduledPaymentStore(), processor, consent)
+	require.NoError(t, err)
+	clock := time.Date(2026, 11, 18, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, processor, consent, &clock
+}
+
+func testScheduledPaymentRequest(transactionID, userID string, amount float64) *PaymentRequest {
+	return &PaymentRequest{
+		TransactionID:  transactionID,
+		TenantID:       "tenant-1",
+		MerchantID:     "merchant-1",
+		UserID:         userID,
+		RegionCode:     RegionUSA,
+		PaymentMethod:  PaymentMethodCard,
+		Amount:         amount,
+		Currency:       "USD",
+		PaymentDetails: map[string]interface{}{"beneficiary_name": "Maria Silva", "beneficiary_country": "US"},
+	}
+}
+
+func testDate(value string) time.Time {
+	date, _ := time.Parse(bankHolidayDateLayout, value)
+	return date
+}
+
+func TestBankHolidayCalendars(t *testing.T) {
+	assert.Equal(t, testDate("2024-03-31"), easterSunday(2024))
+	assert.Equal(t, testDate("2025-04-20"), easterSunday(2025))
+	assert.Equal(t, testDate("2026-04-05"), easterSunday(2026))
+
+	calendars := DefaultBankHolidayCalendars()
+	tests := []struct {
+		market    string
+		date      string
+		holiday   bool
+		execution string
+	}{
+		{RegionBrazil, "2026-11-20", true, "2026-11-23"}, // Consciência Negra numa sexta-feira
+		{RegionBrazil, "2026-02-16", true, "2026-02-18"}, // Carnaval
+		{RegionBrazil, "2026-06-04", true, "2026-06-05"}, // Corpus Christi
+		{RegionEU, "2026-04-03", true, "2026-04-07"},     // Sexta-feira Santa e Segunda-feira de Páscoa
+		{RegionAngola, "2026-02-04", true, "2026-02-05"},
+		{RegionMozambique, "2026-06-25", true, "2026-06-26"},
+		{RegionUSA, "2026-07-04", true, "2026-07-06"}, // Sábado
+		{RegionEU, "2026-11-21", false, "2026-11-23"}, // Fim de semana
+		{RegionEU, "2026-11-18", false, "2026-11-18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.market+" "+tt.date, func(t *testing.T) {
+			calendar := calendars[tt.market]
+			assert.Equal(t, tt.holiday, calendar.IsHoliday(testDate(tt.date)))
+			assert.Equal(t, testDate(tt.execution), calendar.NextBusinessDay(testDate(tt.date)))
+		})
+	}
+	assert.Equal(t, testDate("2026-04-02"), calendars[RegionEU].PreviousBusinessDay(testDate("2026-04-07")))
+}
+
+func TestScheduledPaymentMarketHolidaysConfigured(t *testing.T) {
+	service, _, _, _ := newTestScheduledPaymentService(t)
+
+	day := service.BusinessDay(RegionUSA, testDate("2026-11-26"))
+	assert.True(t, day.Holiday)
+	assert.False(t, day.BusinessDay)
+	assert.Equal(t, testDate("2026-11-27"), day.ExecutionDate)
+
+	// Mercado sem calendário próprio usa o global
+	assert.True(t, service.BusinessDay("XX", testDate("2026-12-25")).Holiday)
+
+	_, err := NewScheduledPaymentService(ScheduledPaymentConfig{
+		MarketBankHolidays: map[string][]string{RegionUSA: {"26/11/2026"}},
+	}, NewInMemoryScheduledPaymentStore(), &fakeScheduledPaymentProcessor{}, &fakePaymentConsentClient{})
+	assert.Error(t, err)
+}
+
+func TestScheduledPaymentPreauthorization(t *testing.T) {
+	service, _, consent, _ := newTestScheduledPaymentService(t)
+	ctx := context.Background()
+	consent.revoke("user-sem-consentimento")
+
+	// Feriado no mercado: a execução passa para o dia útil seguinte e o cancelamento termina no fim
+	// do dia útil anterior
+	payment, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-agendado", "user-1", 120), testDate("2026-11-26"))
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledPaymentStatusScheduled, payment.Status)
+	assert.Equal(t, testDate("2026-11-26"), payment.RequestedDate)
+	assert.Equal(t, testDate("2026-11-27"), payment.ExecutionDate)
+	assert.Equal(t, testDate("2026-11-26"), payment.CancellableUntil)
+	assert.Equal(t, payment.ID, payment.Request.Metadata["scheduled_payment_id"])
+	assert.Equal(t, "2026-11-27", payment.Request.Metadata["scheduled_execution_date"])
+
+	for _, date := range []string{"2026-11-18", "2026-11-17", "2027-11-19"} {
+		_, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-data", "user-1", 120), testDate(date))
+		assert.ErrorIs(t, err, ErrScheduledPaymentDateInvalid, date)
+	}
+
+	_, err = service.Schedule(ctx, testScheduledPaymentRequest("tx-sem-valor", "user-1", 0), testDate("2026-11-20"))
+	assert.ErrorIs(t, err, ErrScheduledPaymentInvalid)
+
+	tests := []struct {
+		name   string
+		modify func(req *PaymentRequest)
+		check  string
+	}{
+		{"acima do limite", func(req *PaymentRequest) { req.Amount = 150000 }, ScheduledPaymentCheckLimits},
+		{"país sancionado", func(req *PaymentRequest) { req.PaymentDetails["beneficiary_country"] = "IR" }, ScheduledPaymentCheckSanctions},
+		{"país de cobrança sancionado", func(req *PaymentRequest) { req.BillingAddress = &Address{Country: "kp"} }, ScheduledPaymentCheckSanctions},
+		{"beneficiário na lista SDN", func(req *PaymentRequest) {
+			req.PaymentDetails["beneficiary_name"] = " sanctioned  trading CO"
+		}, ScheduledPaymentCheckSanctions},
+		{"sem consentimento", func(req *PaymentRequest) { req.UserID = "user-sem-consentimento" }, ScheduledPaymentCheckConsent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testScheduledPaymentRequest("tx-recusado", "user-1", 120)
+			tt.modify(req)
+			_, err := service.Schedule(ctx, req, testDate("2026-11-20"))
+			require.ErrorIs(t, err, ErrScheduledPaymentRejected)
+			assert.Contains(t, err.Error(), tt.check)
+		})
+	}
+
+	payments, err := service.List(ctx, ScheduledPaymentFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+}
+
+func TestScheduledPaymentCancellationWindow(t *testing.T) {
+	service, _, _, clock := newTestScheduledPaymentService(t)
+	ctx := context.Background()
+
+	first, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-primeiro", "user-1", 80), testDate("2026-11-23"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 11, 21, 0, 0, 0, 0, time.UTC), first.CancellableUntil)
+	second, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-segundo", "user-1", 90), testDate("2026-11-23"))
+	require.NoError(t, err)
+
+	// Até ao fim do dia útil anterior à execução o cliente pode cancelar
+	*clock = time.Date(2026, 11, 20, 23, 0, 0, 0, time.UTC)
+	cancelled, err := service.Cancel(ctx, "tenant-1", first.ID, "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledPaymentStatusCancelled, cancelled.Status)
+	assert.Equal(t, "agent-1", cancelled.CancelledBy)
+
+	_, err = service.Cancel(ctx, "tenant-1", first.ID, "agent-1")
+	assert.ErrorIs(t, err, ErrScheduledPaymentNotScheduled)
+
+	*clock = time.Date(2026, 11, 21, 1, 0, 0, 0, time.UTC)
+	_, err = service.Cancel(ctx, "tenant-1", second.ID, "agent-1")
+	assert.ErrorIs(t, err, ErrScheduledPaymentCancellationClosed)
+
+	// Outro tenant não vê o pagamento
+	_, err = service.Cancel(ctx, "tenant-2", second.ID, "agent-1")
+	assert.ErrorIs(t, err, ErrScheduledPaymentNotFound)
+}
+
+func TestScheduledPaymentExecutionRevalidates(t *testing.T) {
+	service, processor, consent, clock := newTestScheduledPaymentService(t)
+	ctx := context.Background()
+
+	approved, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-agendado-1", "user-1", 120), testDate("2026-11-19"))
+	require.NoError(t, err)
+	revoked, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-agendado-2", "user-2", 75), testDate("2026-11-19"))
+	require.NoError(t, err)
+	later, err := service.Schedule(ctx, testScheduledPaymentRequest("tx-agendado-3", "user-1", 50), testDate("2026-11-20"))
+	require.NoError(t, err)
+
+	assert.Empty(t, service.ExecuteDue(ctx))
+
+	// O cliente revoga o consentimento antes da data de execução
+	consent.revoke("user-2")
+	*clock = time.Date(2026, 11, 19, 0, 30, 0, 0, time.UTC)
+	assert.Len(t, service.ExecuteDue(ctx), 2)
+	assert.Equal(t, []string{"tx-agendado-1"}, processor.calls())
+
+	payment, err := service.Get(ctx, "tenant-1", approved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledPaymentStatusExecuted, payment.Status)
+	assert.Equal(t, "AUTH-tx-agendado-1", payment.Reference)
+	require.NotNil(t, payment.ExecutedAt)
+
+	payment, err = service.Get(ctx, "tenant-1", revoked.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledPaymentStatusFailed, payment.Status)
+	assert.Contains(t, payment.FailureReason, ScheduledPaymentCheckConsent)
+	assert.Empty(t, payment.Reference)
+
+	payment, err = service.Get(ctx, "tenant-1", later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledPaymentStatusScheduled, payment.Status)
+
+	// Um pagamento executado não volta a ser reservado nem pode ser cancelado
+	assert.Empty(t, service.ExecuteDue(ctx))
+	_, err = service.Cancel(ctx, "tenant-1", approved.ID, "agent-1")
+	assert.ErrorIs(t, err, ErrScheduledPaymentNotScheduled)
+	executed, err := service.List(ctx, ScheduledPaymentFilter{TenantID: "tenant-1", Status: ScheduledPaymentStatusExecuted})
+	require.NoError(t, err)
+	assert.Len(t, executed, 1)
+}
+
+func TestScheduledPaymentHandler(t *testing.T) {
+	service, _, _, _ := newTestScheduledPaymentService(t)
+	router := mux.NewRouter()
+	NewScheduledPaymentHandler(service).RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/support/scheduled-payments", strings.NewReader(
+		`{"execution_date": "2026-11-26", "payment": {"merchant_id": "merchant-1", "user_id": "user-1",
+		"region_code": "US", "payment_method": "card", "amount": 80, "currency": "USD"}}`))
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var payment ScheduledPayment
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payment))
+	assert.Equal(t, "tenant-1", payment.TenantID)
+	assert.Equal(t, testDate("2026-11-27"), payment.ExecutionDate)
+
+	req = httptest.NewRequest(http.MethodPost, "/support/scheduled-payments", strings.NewReader(
+		`{"execution_date": "2026-11-26", "payment": {"merchant_id": "merchant-1", "user_id": "user-1",
+		"region_code": "US", "payment_method": "card", "amount": 5000, "currency": "USD"}}`))
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/support/scheduled-payments/"+payment.ID+"/cancel", nil)
+	req = req.WithContext(WithSupportPrincipal(req.Context(), &SupportPrincipal{Operator: "agent-1"}))
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payment))
+	assert.Equal(t, "agent-1", payment.CancelledBy)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/scheduled-payments/calendar?market=US&date=2026-11-26", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var day BusinessDay
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &day))
+	assert.False(t, day.BusinessDay)
+	assert.Equal(t, testDate("2026-11-27"), day.ExecutionDate)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/scheduled-payments/inexistente", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,136 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScheduledPaymentStore define a persistência dos pagamentos agendados
+type ScheduledPaymentStore interface {
+	// CreateScheduledPayment grava um novo pagamento agendado e o pedido a executar
+	CreateScheduledPayment(ctx context.Context, payment *ScheduledPayment) error
+
+	// UpdateScheduledPayment grava o pagamento se o estado atual for fromStatus; retorna
+	// ErrScheduledPaymentNotScheduled quando o estado já mudou
+	UpdateScheduledPayment(ctx context.Context, payment *ScheduledPayment, fromStatus string) error
+
+	// GetScheduledPayment recupera o pagamento do tenant; retorna ErrScheduledPaymentNotFound quando não existe
+	GetScheduledPayment(ctx context.Context, tenantID, paymentID string) (*ScheduledPayment, error)
+
+	// ListScheduledPayments lista os pagamentos do filtro por data de execução e ordem de agendamento
+	ListScheduledPayments(ctx context.Context, filter ScheduledPaymentFilter) ([]*ScheduledPayment, error)
+
+	// ClaimDueScheduledPayments reserva para execução os pagamentos agendados com data de execução até
+	// now, para que cada pagamento seja executado por uma única réplica
+	ClaimDueScheduledPayments(ctx context.Context, now time.Time) ([]*ScheduledPayment, error)
+}
+
+// InMemoryScheduledPaymentStore armazena os pagamentos agendados em memória
+type InMemoryScheduledPaymentStore struct {
+	payments map[string]*ScheduledPayment
+	mutex    sync.RWMutex
+}
+
+// NewInMemoryScheduledPaymentStore cria um novo armazenamento em memória
+func NewInMemoryScheduledPaymentStore() *InMemoryScheduledPaymentStore {
+	return &InMemoryScheduledPaymentStore{payments: make(map[string]*ScheduledPayment)}
+}
+
+// CreateScheduledPayment grava uma cópia do pagamento
+func (s *InMemoryScheduledPaymentStore) CreateScheduledPayment(ctx context.Context, payment *ScheduledPayment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.payments[payment.TenantID+"/"+payment.ID] = copyScheduledPayment(payment)
+	return nil
+}
+
+// UpdateScheduledPayment grava uma cópia do pagamento se o estado atual for fromStatus
+func (s *InMemoryScheduledPaymentStore) UpdateScheduledPayment(ctx context.Context, payment *ScheduledPayment, fromStatus string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := payment.TenantID + "/" + payment.ID
+	current, ok := s.payments[key]
+	if !ok {
+		return ErrScheduledPaymentNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrScheduledPaymentNotScheduled
+	}
+	s.payments[key] = copyScheduledPayment(payment)
+	return nil
+}
+
+// GetScheduledPayment retorna uma cópia do pagamento do tenant
+func (s *InMemoryScheduledPaymentStore) GetScheduledPayment(ctx context.Context, tenantID, paymentID string) (*ScheduledPayment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	payment, ok := s.payments[tenantID+"/"+paymentID]
+	if !ok {
+		return nil, ErrScheduledPaymentNotFound
+	}
+	return copyScheduledPayment(payment), nil
+}
+
+// ListScheduledPayments retorna cópias dos pagamentos do filtro
+func (s *InMemoryScheduledPaymentStore) ListScheduledPayments(ctx context.Context, filter ScheduledPaymentFilter) ([]*ScheduledPayment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	payments := make([]*ScheduledPayment, 0)
+	for _, payment := range s.payments {
+		if filter.TenantID != "" && payment.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.UserID != "" && payment.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != "" && payment.Status != filter.Status {
+			continue
+		}
+		payments = append(payments, copyScheduledPayment(payment))
+	}
+	sortScheduledPayments(payments)
+	return payments, nil
+}
+
+// ClaimDueScheduledPayments marca como em execução os pagamentos agendados vencidos
+func (s *InMemoryScheduledPaymentStore) ClaimDueScheduledPayments(ctx context.Context, now time.Time) ([]*ScheduledPayment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	payments := make([]*ScheduledPayment, 0)
+	for _, payment := range s.payments {
+		if payment.Status != ScheduledPaymentStatusScheduled || payment.ExecutionDate.After(now) {
+			continue
+		}
+		payment.Status = ScheduledPaymentStatusExecuting
+		payments = append(payments, copyScheduledPayment(payment))
+	}
+	sortScheduledPayments(payments)
+	return payments, nil
+}
+
+// copyScheduledPayment copia o pagamento e o pedido a executar
+func copyScheduledPayment(payment *ScheduledPayment) *ScheduledPayment {
+	copied := *payment
+	if payment.Request != nil {
+		request := *payment.Request
+		copied.Request = &request
+	}
+	return &copied
+}
+
+// sortScheduledPayments ordena os pagamentos por data de execução e, no mesmo dia, por ordem de agendamento
+func sortScheduledPayments(payments []*ScheduledPayment) {
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].ExecutionDate.Equal(payments[j].ExecutionDate) {
+			return payments[i].ExecutionDate.Before(payments[j].ExecutionDate)
+		}
+		return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+	})
+}
@@ -0,0 +1,193 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// SegmentLimitHandler expõe a API HTTP de administração das matrizes de limites por segmento
+type SegmentLimitHandler struct {
+	service *SegmentLimitService
+}
+
+// NewSegmentLimitHandler cria uma nova instância do SegmentLimitHandler
+func NewSegmentLimitHandler(service *SegmentLimitService) *SegmentLimitHandler {
+	return &SegmentLimitHandler{service: service}
+}
+
+// limitMatrixRequest representa a requisição de substituição da matriz de limites
+type limitMatrixRequest struct {
+	Version int64              `json:"version"` // Versão da matriz sobre a qual a alteração foi preparada
+	Rules   []SegmentLimitRule `json:"rules"`
+	ActorID string             `json:"actor_id"`
+	Reason  string             `json:"reason"`
+}
+
+// limitRuleRequest representa a requisição de criação ou atualização de uma regra
+type limitRuleRequest struct {
+	Rule    SegmentLimitRule `json:"rule"`
+	ActorID string           `json:"actor_id"`
+	Reason  string           `json:"reason"`
+}
+
+// limitRuleDeleteRequest representa a requisição de remoção de uma regra
+type limitRuleDeleteRequest struct {
+	ActorID string `json:"actor_id"`
+	Reason  string `json:"reason"`
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *SegmentLimitHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/limits/matrix", h.GetMatrix).Methods(http.MethodGet)
+	router.HandleFunc("/limits/matrix", h.ReplaceMatrix).Methods(http.MethodPut)
+	router.HandleFunc("/limits/matrix/rules", h.CreateRule).Methods(http.MethodPost)
+	router.HandleFunc("/limits/matrix/rules/{ruleId}", h.UpdateRule).Methods(http.MethodPut)
+	router.HandleFunc("/limits/matrix/rules/{ruleId}", h.DeleteRule).Methods(http.MethodDelete)
+	router.HandleFunc("/limits/resolve", h.ResolveLimit).Methods(http.MethodGet)
+	router.HandleFunc("/limits/audit-events", h.ListAuditEvents).Methods(http.MethodGet)
+}
+
+// GetMatrix retorna a matriz de limites do tenant
+func (h *SegmentLimitHandler) GetMatrix(w http.ResponseWriter, r *http.Request) {
+	matrix, err := h.service.GetMatrix(r.Context(), r.Header.Get("X-Tenant-ID"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, matrix)
+}
+
+// ReplaceMatrix substitui todas as regras da matriz do tenant
+func (h *SegmentLimitHandler) ReplaceMatrix(w http.ResponseWriter, r *http.Request) {
+	var req limitMatrixRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	matrix, err := h.service.ReplaceMatrix(r.Context(), r.Header.Get("X-Tenant-ID"), req.Rules, req.Version, req.ActorID, req.Reason)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, matrix)
+}
+
+// CreateRule acrescenta uma regra à matriz do tenant
+func (h *SegmentLimitHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req limitRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	rule, err := h.service.CreateRule(r.Context(), r.Header.Get("X-Tenant-ID"), req.Rule, req.ActorID, req.Reason)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateRule atualiza uma regra existente da matriz do tenant
+func (h *SegmentLimitHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var req limitRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	rule, err := h.service.UpdateRule(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["ruleId"], req.Rule, req.ActorID, req.Reason)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rule)
+}
+
+// DeleteRule remove uma regra da matriz do tenant
+func (h *SegmentLimitHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	var req limitRuleDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	if err := h.service.DeleteRule(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["ruleId"], req.ActorID, req.Reason); err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResolveLimit retorna o limite que seria aplicado a um pagamento do segmento indicado
+func (h *SegmentLimitHandler) ResolveLimit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := LimitResolutionRequest{
+		TenantID:         r.Header.Get("X-Tenant-ID"),
+		PaymentType:      query.Get("payment_type"),
+		CustomerTier:     query.Get("customer_tier"),
+		MerchantCategory: query.Get("merchant_category"),
+		Market:           query.Get("market"),
+	}
+	if req.PaymentType == "" {
+		req.PaymentType = LimitPaymentTypeStandard
+	}
+
+	resolved, err := h.service.Resolve(r.Context(), req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+	if resolved == nil {
+		respondWithError(w, http.StatusNotFound, "limit_rule_not_found", "Nenhuma regra de limite aplicável ao segmento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resolved)
+}
+
+// ListAuditEvents retorna as alterações das matrizes do tenant no período (AAAA-MM-DD, fim exclusivo)
+func (h *SegmentLimitHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro from inválido (AAAA-MM-DD)")
+		return
+	}
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil || !to.After(from) {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro to inválido (AAAA-MM-DD, posterior a from)")
+		return
+	}
+
+	events, err := h.service.AuditEvents(r.Context(), r.Header.Get("X-Tenant-ID"), from, to)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, events)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *SegmentLimitHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrLimitRuleNotFound):
+		respondWithError(w, http.StatusNotFound, "limit_rule_not_found", err.Error())
+	case errors.Is(err, ErrLimitRuleInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrLimitMatrixVersionConflict):
+		respondWithError(w, http.StatusConflict, "version_conflict", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "limits_error", err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Curinga que corresponde a qualquer valor numa dimensão do segmento
+const SegmentWildcard = "*"
+
+// Chaves de tipo de pagamento usadas na resolução de limites
+const (
+	LimitPaymentTypeStandard      = "standard"
+	LimitPaymentTypeHighRisk      = "high_risk"
+	LimitPaymentTypeInternational = "international"
+	LimitPaymentTypeRecurring     = "recurring"
+)
+
+// Tipos de evento de auditoria das matrizes de limites
+const (
+	LimitEventRuleCreated    = "LIMIT_RULE_CREATED"
+	LimitEventRuleUpdated    = "LIMIT_RULE_UPDATED"
+	LimitEventRuleDeleted    = "LIMIT_RULE_DELETED"
+	LimitEventMatrixReplaced = "LIMIT_MATRIX_REPLACED"
+)
+
+// Valores padrão do serviço de limites por segmento
+const (
+	DefaultSegmentLimitCacheTTL = time.Minute
+)
+
+// Erros do serviço de limites por segmento
+var (
+	ErrLimitRuleNotFound          = errors.New("regra de limite não encontrada")
+	ErrLimitRuleInvalid           = errors.New("regra de limite inválida")
+	ErrLimitMatrixVersionConflict = errors.New("matriz de limites alterada por outro pedido")
+)
+
+// LimitSegment identifica o segmento de clientes a que uma regra se aplica
+// Dimensões vazias ou "*" correspondem a qualquer valor
+type LimitSegment struct {
+	CustomerTier     string `json:"customer_tier,omitempty"`
+	MerchantCategory string `json:"merchant_category,omitempty"`
+	Market           string `json:"market,omitempty"`
+}
+
+// SegmentLimitRule define os limites de um tipo de pagamento para um segmento
+type SegmentLimitRule struct {
+	RuleID      string           `json:"rule_id"`
+	PaymentType string           `json:"payment_type"` // standard, high_risk, international, recurring ou "*"
+	Segment     LimitSegment     `json:"segment"`
+	Limit       TransactionLimit `json:"limit"`
+	Description string           `json:"description,omitempty"`
+	UpdatedBy   string           `json:"updated_by"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// LimitMatrix reúne as regras de limite de um tenant
+type LimitMatrix struct {
+	TenantID  string             `json:"tenant_id"`
+	Version   int64              `json:"version"`
+	Rules     []SegmentLimitRule `json:"rules"`
+	UpdatedBy string             `json:"updated_by,omitempty"`
+	UpdatedAt time.Time          `json:"updated_at,omitempty"`
+}
+
+// LimitResolutionRequest identifica o pagamento para o qual o limite é resolvido
+type LimitResolutionRequest struct {
+	TenantID         string `json:"tenant_id"`
+	PaymentType      string `json:"payment_type"`
+	CustomerTier     string `json:"customer_tier"`
+	MerchantCategory string `json:"merchant_category"`
+	Market           string `json:"market"`
+}
+
+// ResolvedLimit é o limite aplicável a um pagamento e a regra que o definiu
+type ResolvedLimit struct {
+	Limit         TransactionLimit `json:"limit"`
+	RuleID        string           `json:"rule_id"`
+	MatrixVersion int64            `json:"matrix_version"`
+}
+
+// LimitAuditEvent regista uma alteração da matriz de limites de um tenant
+type LimitAuditEvent struct {
+	EventID       string            `json:"event_id"`
+	EventType     string            `json:"event_type"`
+	TenantID      string            `json:"tenant_id"`
+	RuleID        string            `json:"rule_id,omitempty"`
+	Before        *SegmentLimitRule `json:"before,omitempty"`
+	After         *SegmentLimitRule `json:"after,omitempty"`
+	MatrixVersion int64             `json:"matrix_version"`
+	Actor         string            `json:"actor"`
+	Reason        string            `json:"reason,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+}
@@ -0,0 +1,490 @@
+package paymentgateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// SegmentLimitConfig contém configurações do serviço de limites por segmento
+type SegmentLimitConfig struct {
+	// Tempo durante o qual a matriz de um tenant é servida da cache sem consultar o armazenamento
+	CacheTTL time.Duration `json:"cache_ttl"`
+}
+
+// cachedLimitMatrix é uma matriz de limites mantida na cache
+type cachedLimitMatrix struct {
+	matrix   *LimitMatrix
+	loadedAt time.Time
+}
+
+// SegmentLimitService resolve os limites de transação por tipo de pagamento e segmento de cliente
+// (nível do cliente, categoria do comerciante e mercado) e gerencia as matrizes de limites dos tenants
+type SegmentLimitService struct {
+	config SegmentLimitConfig
+	store  SegmentLimitStore
+	cache  map[string]cachedLimitMatrix
+	mutex  sync.RWMutex
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewSegmentLimitService cria o serviço de limites por segmento
+func NewSegmentLimitService(config SegmentLimitConfig, store SegmentLimitStore) (*SegmentLimitService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-segment-limits",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultSegmentLimitCacheTTL
+	}
+
+	return &SegmentLimitService{
+		config:          config,
+		store:           store,
+		cache:           make(map[string]cachedLimitMatrix),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}, nil
+}
+
+// SetClock substitui o relógio usado na validade da cache e na data das alterações
+func (s *SegmentLimitService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Resolve retorna o limite da regra mais específica para o pagamento, ou nil se nenhuma regra se aplicar
+// Uma regra do tipo de pagamento prevalece sobre uma regra "*"; depois, prevalece a que fixa mais
+// dimensões do segmento e, em empate, a mais restritiva
+func (s *SegmentLimitService) Resolve(ctx context.Context, req LimitResolutionRequest) (*ResolvedLimit, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "SegmentLimitService.Resolve")
+	defer span.End()
+
+	matrix, err := s.cachedMatrix(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *SegmentLimitRule
+	bestScore := -1
+	for i := range matrix.Rules {
+		rule := &matrix.Rules[i]
+		score, ok := ruleSpecificity(rule, req)
+		if !ok {
+			continue
+		}
+		if score > bestScore || (score == bestScore && moreRestrictive(rule, best)) {
+			best, bestScore = rule, score
+		}
+	}
+
+	result := "matched"
+	if best == nil {
+		result = "no_match"
+	}
+	s.metricsRecorder.CounterInc("payment_segment_limit_resolutions_total", map[string]string{
+		"tenant_id":    req.TenantID,
+		"payment_type": req.PaymentType,
+		"result":       result,
+	})
+
+	if best == nil {
+		return nil, nil
+	}
+	return &ResolvedLimit{
+		Limit:         best.Limit,
+		RuleID:        best.RuleID,
+		MatrixVersion: matrix.Version,
+	}, nil
+}
+
+// GetMatrix retorna a matriz de limites atual do tenant, sem passar pela cache
+func (s *SegmentLimitService) GetMatrix(ctx context.Context, tenantID string) (*LimitMatrix, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("%w: tenant obrigatório", ErrLimitRuleInvalid)
+	}
+	return s.store.GetMatrix(ctx, tenantID)
+}
+
+// ReplaceMatrix substitui todas as regras do tenant, se a matriz ainda estiver em expectedVersion
+func (s *SegmentLimitService) ReplaceMatrix(ctx context.Context, tenantID string, rules []SegmentLimitRule, expectedVersion int64, actor, reason string) (*LimitMatrix, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "SegmentLimitService.ReplaceMatrix")
+	defer span.End()
+
+	current, err := s.GetMatrix(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Version != expectedVersion {
+		return nil, ErrLimitMatrixVersionConflict
+	}
+
+	next := &LimitMatrix{TenantID: tenantID, Rules: append([]SegmentLimitRule{}, rules...)}
+	return s.saveMatrix(ctx, current, next, actor, reason, true)
+}
+
+// CreateRule acrescenta uma regra à matriz do tenant
+func (s *SegmentLimitService) CreateRule(ctx context.Context, tenantID string, rule SegmentLimitRule, actor, reason string) (*SegmentLimitRule, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "SegmentLimitService.CreateRule")
+	defer span.End()
+
+	current, err := s.GetMatrix(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.RuleID = ""
+	next := copyLimitMatrix(current)
+	next.Rules = append(next.Rules, rule)
+
+	saved, err := s.saveMatrix(ctx, current, next, actor, reason, false)
+	if err != nil {
+		return nil, err
+	}
+	return &saved.Rules[len(saved.Rules)-1], nil
+}
+
+// UpdateRule substitui o conteúdo de uma regra existente da matriz do tenant
+func (s *SegmentLimitService) UpdateRule(ctx context.Context, tenantID, ruleID string, rule SegmentLimitRule, actor, reason string) (*SegmentLimitRule, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "SegmentLimitService.UpdateRule")
+	defer span.End()
+
+	current, err := s.GetMatrix(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.RuleID = ruleID
+	next := copyLimitMatrix(current)
+	index := -1
+	for i := range next.Rules {
+		if next.Rules[i].RuleID == ruleID {
+			next.Rules[i] = rule
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, ErrLimitRuleNotFound
+	}
+
+	saved, err := s.saveMatrix(ctx, current, next, actor, reason, false)
+	if err != nil {
+		return nil, err
+	}
+	return &saved.Rules[index], nil
+}
+
+// DeleteRule remove uma regra da matriz do tenant
+func (s *SegmentLimitService) DeleteRule(ctx context.Context, tenantID, ruleID, actor, reason string) error {
+	ctx, span := s.tracer.StartSpan(ctx, "SegmentLimitService.DeleteRule")
+	defer span.End()
+
+	current, err := s.GetMatrix(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	next := &LimitMatrix{TenantID: tenantID, Rules: make([]SegmentLimitRule, 0, len(current.Rules))}
+	for _, rule := range current.Rules {
+		if rule.RuleID != ruleID {
+			next.Rules = append(next.Rules, rule)
+		}
+	}
+	if len(next.Rules) == len(current.Rules) {
+		return ErrLimitRuleNotFound
+	}
+
+	_, err = s.saveMatrix(ctx, current, next, actor, reason, false)
+	return err
+}
+
+// AuditEvents retorna os eventos de alteração das matrizes do tenant no período
+func (s *SegmentLimitService) AuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*LimitAuditEvent, error) {
+	return s.store.ListAuditEvents(ctx, tenantID, from, to)
+}
+
+// InvalidateCache descarta a matriz do tenant em cache, ou todas se tenantID for vazio
+func (s *SegmentLimitService) InvalidateCache(tenantID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if tenantID == "" {
+		s.cache = make(map[string]cachedLimitMatrix)
+		return
+	}
+	delete(s.cache, tenantID)
+}
+
+// cachedMatrix retorna a matriz do tenant da cache ou do armazenamento
+// Se o armazenamento falhar, a última matriz conhecida continua a ser usada
+func (s *SegmentLimitService) cachedMatrix(ctx context.Context, tenantID string) (*LimitMatrix, error) {
+	now := s.now()
+
+	s.mutex.RLock()
+	cached, ok := s.cache[tenantID]
+	s.mutex.RUnlock()
+	if ok && now.Sub(cached.loadedAt) < s.config.CacheTTL {
+		return cached.matrix, nil
+	}
+
+	matrix, err := s.store.GetMatrix(ctx, tenantID)
+	if err != nil {
+		if ok {
+			s.logger.WarnWithContext(ctx, "Falha ao atualizar matriz de limites, usando versão em cache",
+				"tenant_id", tenantID,
+				"matrix_version", cached.matrix.Version,
+				"error", err.Error())
+			return cached.matrix, nil
+		}
+		return nil, fmt.Errorf("falha ao obter matriz de limites: %w", err)
+	}
+
+	s.mutex.Lock()
+	s.cache[tenantID] = cachedLimitMatrix{matrix: matrix, loadedAt: now}
+	s.mutex.Unlock()
+	return matrix, nil
+}
+
+// saveMatrix valida e grava a nova matriz e regista os eventos de auditoria das regras alteradas
+func (s *SegmentLimitService) saveMatrix(ctx context.Context, current, next *LimitMatrix, actor, reason string, replace bool) (*LimitMatrix, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, fmt.Errorf("%w: ator obrigatório", ErrLimitRuleInvalid)
+	}
+
+	now := s.now()
+	previous := make(map[string]SegmentLimitRule, len(current.Rules))
+	for _, rule := range current.Rules {
+		previous[rule.RuleID] = rule
+	}
+
+	seen := make(map[string]bool, len(next.Rules))
+	for i := range next.Rules {
+		rule := &next.Rules[i]
+		normalizeLimitRule(rule)
+		if err := validateLimitRule(rule); err != nil {
+			return nil, err
+		}
+
+		key := rule.PaymentType + "|" + rule.Segment.CustomerTier + "|" + rule.Segment.MerchantCategory + "|" + rule.Segment.Market
+		if seen[key] {
+			return nil, fmt.Errorf("%w: já existe uma regra para o tipo %s no segmento %+v", ErrLimitRuleInvalid, rule.PaymentType, rule.Segment)
+		}
+		seen[key] = true
+
+		if rule.RuleID == "" {
+			rule.RuleID = fmt.Sprintf("lim-%s", uuid.New().String())
+		}
+		if before, ok := previous[rule.RuleID]; ok && sameLimitRule(before, *rule) {
+			// Regra inalterada mantém o autor e a data da última alteração
+			rule.UpdatedBy, rule.UpdatedAt = before.UpdatedBy, before.UpdatedAt
+			continue
+		}
+		rule.UpdatedBy, rule.UpdatedAt = actor, now
+	}
+
+	next.TenantID = current.TenantID
+	next.UpdatedBy = actor
+	next.UpdatedAt = now
+	if err := s.store.SaveMatrix(ctx, next, current.Version); err != nil {
+		return nil, err
+	}
+
+	events := limitAuditEvents(current, next, actor, reason, now)
+	if replace {
+		events = append(events, &LimitAuditEvent{
+			EventID:       uuid.New().String(),
+			EventType:     LimitEventMatrixReplaced,
+			TenantID:      next.TenantID,
+			MatrixVersion: next.Version,
+			Actor:         actor,
+			Reason:        reason,
+			OccurredAt:    now,
+		})
+	}
+	if err := s.store.SaveAuditEvents(ctx, events); err != nil {
+		// A alteração já foi gravada; a falha de auditoria é registada para reconciliação
+		s.logger.ErrorWithContext(ctx, "Falha ao gravar eventos de auditoria da matriz de limites",
+			"tenant_id", next.TenantID,
+			"matrix_version", next.Version,
+			"error", err.Error())
+	}
+	for _, event := range events {
+		s.metricsRecorder.CounterInc("payment_segment_limit_audit_events_total", map[string]string{
+			"event_type": event.EventType,
+		})
+	}
+
+	s.InvalidateCache(next.TenantID)
+
+	s.logger.InfoWithContext(ctx, "Matriz de limites atualizada",
+		"tenant_id", next.TenantID,
+		"matrix_version", next.Version,
+		"rules", len(next.Rules),
+		"changes", len(events),
+		"actor", actor)
+
+	return copyLimitMatrix(next), nil
+}
+
+// limitAuditEvents compara as regras das duas versões da matriz e gera um evento por regra alterada
+func limitAuditEvents(current, next *LimitMatrix, actor, reason string, now time.Time) []*LimitAuditEvent {
+	previous := make(map[string]SegmentLimitRule, len(current.Rules))
+	for _, rule := range current.Rules {
+		previous[rule.RuleID] = rule
+	}
+
+	var events []*LimitAuditEvent
+	newEvent := func(eventType, ruleID string, before, after *SegmentLimitRule) {
+		events = append(events, &LimitAuditEvent{
+			EventID:       uuid.New().String(),
+			EventType:     eventType,
+			TenantID:      next.TenantID,
+			RuleID:        ruleID,
+			Before:        before,
+			After:         after,
+			MatrixVersion: next.Version,
+			Actor:         actor,
+			Reason:        reason,
+			OccurredAt:    now,
+		})
+	}
+
+	for i := range next.Rules {
+		after := next.Rules[i]
+		before, existed := previous[after.RuleID]
+		delete(previous, after.RuleID)
+		switch {
+		case !existed:
+			newEvent(LimitEventRuleCreated, after.RuleID, nil, &after)
+		case !sameLimitRule(before, after):
+			newEvent(LimitEventRuleUpdated, after.RuleID, &before, &after)
+		}
+	}
+
+	removed := make([]string, 0, len(previous))
+	for ruleID := range previous {
+		removed = append(removed, ruleID)
+	}
+	sort.Strings(removed)
+	for _, ruleID := range removed {
+		before := previous[ruleID]
+		newEvent(LimitEventRuleDeleted, ruleID, &before, nil)
+	}
+	return events
+}
+
+// normalizeLimitRule converte dimensões vazias no curinga e normaliza maiúsculas
+func normalizeLimitRule(rule *SegmentLimitRule) {
+	normalize := func(value string, upper bool) string {
+		value = strings.TrimSpace(value)
+		if value == "" || value == SegmentWildcard {
+			return SegmentWildcard
+		}
+		if upper {
+			return strings.ToUpper(value)
+		}
+		return strings.ToLower(value)
+	}
+	rule.PaymentType = normalize(rule.PaymentType, false)
+	rule.Segment.CustomerTier = normalize(rule.Segment.CustomerTier, false)
+	rule.Segment.MerchantCategory = normalize(rule.Segment.MerchantCategory, false)
+	rule.Segment.Market = normalize(rule.Segment.Market, true)
+}
+
+// validateLimitRule valida o tipo de pagamento e os valores da regra
+func validateLimitRule(rule *SegmentLimitRule) error {
+	switch rule.PaymentType {
+	case SegmentWildcard, LimitPaymentTypeStandard, LimitPaymentTypeHighRisk,
+		LimitPaymentTypeInternational, LimitPaymentTypeRecurring:
+	default:
+		return fmt.Errorf("%w: tipo de pagamento desconhecido %q", ErrLimitRuleInvalid, rule.PaymentType)
+	}
+
+	limit := rule.Limit
+	if limit.SingleTransactionMax < 0 || limit.DailyTransactionMax < 0 || limit.MonthlyTransactionMax < 0 || limit.RequiredTrustScore < 0 {
+		return fmt.Errorf("%w: limites não podem ser negativos", ErrLimitRuleInvalid)
+	}
+	if limit.DailyTransactionMax > 0 && limit.SingleTransactionMax > limit.DailyTransactionMax {
+		return fmt.Errorf("%w: limite por transação superior ao limite diário", ErrLimitRuleInvalid)
+	}
+	if limit.MonthlyTransactionMax > 0 && limit.DailyTransactionMax > limit.MonthlyTransactionMax {
+		return fmt.Errorf("%w: limite diário superior ao limite mensal", ErrLimitRuleInvalid)
+	}
+	return nil
+}
+
+// sameLimitRule verifica se duas versões de uma regra têm o mesmo conteúdo
+func sameLimitRule(a, b SegmentLimitRule) bool {
+	return a.PaymentType == b.PaymentType && a.Segment == b.Segment && a.Limit == b.Limit && a.Description == b.Description
+}
+
+// ruleSpecificity verifica se a regra se aplica ao pedido e retorna a sua especificidade
+func ruleSpecificity(rule *SegmentLimitRule, req LimitResolutionRequest) (int, bool) {
+	score := 0
+	dimensions := []struct {
+		rule, value string
+		weight      int
+	}{
+		{rule.PaymentType, req.PaymentType, 8},
+		{rule.Segment.CustomerTier, req.CustomerTier, 1},
+		{rule.Segment.MerchantCategory, req.MerchantCategory, 1},
+		{rule.Segment.Market, req.Market, 1},
+	}
+	for _, d := range dimensions {
+		if d.rule == SegmentWildcard {
+			continue
+		}
+		if !strings.EqualFold(d.rule, d.value) {
+			return 0, false
+		}
+		score += d.weight
+	}
+	return score, true
+}
+
+// moreRestrictive desempata regras com a mesma especificidade pelo menor limite por transação
+func moreRestrictive(rule, best *SegmentLimitRule) bool {
+	if best == nil {
+		return true
+	}
+	a, b := rule.Limit.SingleTransactionMax, best.Limit.SingleTransactionMax
+	switch {
+	case a > 0 && (b == 0 || a < b):
+		return true
+	case a != b:
+		return false
+	}
+	return rule.RuleID < best.RuleID
+}
+
+// limitPaymentType determina o tipo de pagamento usado na resolução de limites
+func limitPaymentType(req *PaymentRequest) string {
+	switch {
+	case req.HighRiskCategory:
+		return LimitPaymentTypeHighRisk
+	case req.InternationalPayment:
+		return LimitPaymentTypeInternational
+	case req.RecurringPayment:
+		return LimitPaymentTypeRecurring
+	default:
+		return LimitPaymentTypeStandard
+	}
+}
@@ -0,0 +1,100 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SegmentLimitStore define a persistência das matrizes de limites e dos seus eventos de auditoria
+type SegmentLimitStore interface {
+	// GetMatrix recupera a matriz do tenant; tenants sem matriz retornam uma matriz vazia na versão 0
+	GetMatrix(ctx context.Context, tenantID string) (*LimitMatrix, error)
+
+	// SaveMatrix grava a matriz se a versão atual for expectedVersion, incrementando a versão
+	SaveMatrix(ctx context.Context, matrix *LimitMatrix, expectedVersion int64) error
+
+	// SaveAuditEvents grava os eventos de auditoria de uma alteração
+	SaveAuditEvents(ctx context.Context, events []*LimitAuditEvent) error
+
+	// ListAuditEvents lista os eventos de auditoria do tenant no período, por ordem cronológica
+	ListAuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*LimitAuditEvent, error)
+}
+
+// InMemorySegmentLimitStore armazena matrizes de limites e eventos de auditoria em memória
+type InMemorySegmentLimitStore struct {
+	matrices map[string]*LimitMatrix
+	events   []*LimitAuditEvent
+	mutex    sync.RWMutex
+}
+
+// NewInMemorySegmentLimitStore cria um novo armazenamento em memória
+func NewInMemorySegmentLimitStore() *InMemorySegmentLimitStore {
+	return &InMemorySegmentLimitStore{matrices: make(map[string]*LimitMatrix)}
+}
+
+// GetMatrix retorna uma cópia da matriz do tenant
+func (s *InMemorySegmentLimitStore) GetMatrix(ctx context.Context, tenantID string) (*LimitMatrix, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	matrix, ok := s.matrices[tenantID]
+	if !ok {
+		return &LimitMatrix{TenantID: tenantID, Rules: []SegmentLimitRule{}}, nil
+	}
+	return copyLimitMatrix(matrix), nil
+}
+
+// SaveMatrix grava uma cópia da matriz com controlo de concorrência otimista
+func (s *InMemorySegmentLimitStore) SaveMatrix(ctx context.Context, matrix *LimitMatrix, expectedVersion int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var current int64
+	if existing, ok := s.matrices[matrix.TenantID]; ok {
+		current = existing.Version
+	}
+	if current != expectedVersion {
+		return ErrLimitMatrixVersionConflict
+	}
+
+	matrix.Version = expectedVersion + 1
+	s.matrices[matrix.TenantID] = copyLimitMatrix(matrix)
+	return nil
+}
+
+// SaveAuditEvents acrescenta os eventos ao registo de auditoria
+func (s *InMemorySegmentLimitStore) SaveAuditEvents(ctx context.Context, events []*LimitAuditEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, event := range events {
+		copied := *event
+		s.events = append(s.events, &copied)
+	}
+	return nil
+}
+
+// ListAuditEvents retorna cópias dos eventos do tenant no período [from, to)
+func (s *InMemorySegmentLimitStore) ListAuditEvents(ctx context.Context, tenantID string, from, to time.Time) ([]*LimitAuditEvent, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	events := []*LimitAuditEvent{}
+	for _, event := range s.events {
+		if event.TenantID == tenantID && !event.OccurredAt.Before(from) && event.OccurredAt.Before(to) {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].OccurredAt.Before(events[j].OccurredAt) })
+	return events, nil
+}
+
+// copyLimitMatrix copia a matriz, incluindo a lista de regras
+func copyLimitMatrix(matrix *LimitMatrix) *LimitMatrix {
+	copied := *matrix
+	copied.Rules = append([]SegmentLimitRule{}, matrix.Rules...)
+	return &copied
+}
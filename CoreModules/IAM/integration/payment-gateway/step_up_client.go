@@ -0,0 +1,120 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// IdentityStepUpClient define as chamadas ao motor de MFA do serviço de identidade
+type IdentityStepUpClient interface {
+	// CreateChallenge pede um desafio de step-up ao usuário; recusas do serviço retornam ErrStepUpRejected
+	CreateChallenge(ctx context.Context, req *StepUpChallengeRequest) (*StepUpChallenge, error)
+
+	// CancelChallenge cancela um desafio que deixou de ser necessário
+	CancelChallenge(ctx context.Context, tenantID, challengeID, reason string) error
+}
+
+// stepUpErrorResponse representa uma recusa devolvida pelo serviço de identidade
+type stepUpErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// HTTPIdentityStepUpClient chama a API de step-up do serviço de identidade por HTTP/JSON
+type HTTPIdentityStepUpClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	policy     resilience.Policy
+}
+
+// NewHTTPIdentityStepUpClient cria o cliente da API de step-up do serviço de identidade
+func NewHTTPIdentityStepUpClient(config StepUpConfig) *HTTPIdentityStepUpClient {
+	timeout := config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultStepUpHTTPTimeout
+	}
+	policy := resilience.DefaultPolicy().Merge(resilience.Policy{Timeout: timeout}).Merge(config.Resilience)
+
+	return &HTTPIdentityStepUpClient{
+		baseURL:    strings.TrimRight(config.IdentityServiceURL, "/"),
+		apiKey:     config.APIKey,
+		httpClient: resilience.NewHTTPClient(policy),
+		policy:     policy,
+	}
+}
+
+// CreateChallenge pede o desafio; a referência da transação torna o pedido repetível
+func (c *HTTPIdentityStepUpClient) CreateChallenge(ctx context.Context, req *StepUpChallengeRequest) (*StepUpChallenge, error) {
+	var challenge StepUpChallenge
+	if err := c.sendJSON(ctx, req.TenantID, "/api/v1/step-up/challenges", "step-up-"+req.Reference, req, &challenge); err != nil {
+		return nil, err
+	}
+	if challenge.ChallengeID == "" {
+		return nil, fmt.Errorf("%w: resposta sem identificador do desafio", ErrStepUpUnavailable)
+	}
+	return &challenge, nil
+}
+
+// CancelChallenge pede o cancelamento do desafio
+func (c *HTTPIdentityStepUpClient) CancelChallenge(ctx context.Context, tenantID, challengeID, reason string) error {
+	endpoint := "/api/v1/step-up/challenges/" + url.PathEscape(challengeID) + "/cancel"
+	return c.sendJSON(ctx, tenantID, endpoint, "cancel-"+challengeID, map[string]string{"reason": reason}, nil)
+}
+
+// sendJSON envia um POST JSON ao serviço de identidade e decodifica a resposta
+// Respostas 4xx indicam recusa do serviço; falhas de rede e respostas 5xx indicam indisponibilidade
+func (c *HTTPIdentityStepUpClient) sendJSON(ctx context.Context, tenantID, endpoint, idempotencyKey string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	target := c.baseURL + endpoint
+
+	resp, err := resilience.DoHTTP(ctx, c.httpClient, c.policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Tenant-ID", tenantID)
+		req.Header.Set(resilience.IdempotencyKeyHeader, idempotencyKey)
+		if c.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStepUpUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStepUpUnavailable, err)
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s retornou status %d", ErrStepUpUnavailable, endpoint, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		var rejection stepUpErrorResponse
+		if json.Unmarshal(body, &rejection) == nil && rejection.Code != "" {
+			return fmt.Errorf("%w: %s %s", ErrStepUpRejected, rejection.Code, rejection.Message)
+		}
+		return fmt.Errorf("%w: %s retornou status %d", ErrStepUpRejected, endpoint, resp.StatusCode)
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
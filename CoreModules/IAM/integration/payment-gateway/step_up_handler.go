@@ -0,0 +1,72 @@
+package paymentgateway
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Tamanho máximo de uma notificação de conclusão enviada pelo serviço de identidade
+const maxStepUpCallbackBytes = 64 << 10
+
+// StepUpHandler expõe o estado dos step-ups de pagamento e recebe as notificações do serviço de identidade
+type StepUpHandler struct {
+	service *StepUpService
+}
+
+// NewStepUpHandler cria uma nova instância do StepUpHandler
+func NewStepUpHandler(service *StepUpService) *StepUpHandler {
+	return &StepUpHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *StepUpHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/payments/step-up/callback", h.HandleCallback).Methods(http.MethodPost)
+	router.HandleFunc("/payments/{transactionId}/step-up", h.GetStepUp).Methods(http.MethodGet)
+}
+
+// GetStepUp retorna o estado do step-up de MFA de um pagamento e a resposta final quando resolvido
+func (h *StepUpHandler) GetStepUp(w http.ResponseWriter, r *http.Request) {
+	stepUp, err := h.service.GetStepUp(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["transactionId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stepUp)
+}
+
+// HandleCallback recebe a conclusão, falha, cancelamento ou expiração de um desafio de MFA
+// O corpo é verificado com a assinatura HMAC antes de ser interpretado
+func (h *StepUpHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxStepUpCallbackBytes))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	stepUp, err := h.service.HandleCallback(r.Context(), payload,
+		r.Header.Get(StepUpTimestampHeader), r.Header.Get(StepUpSignatureHeader))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stepUp)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *StepUpHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrStepUpCallbackInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrStepUpSignatureInvalid):
+		respondWithError(w, http.StatusUnauthorized, "invalid_signature", err.Error())
+	case errors.Is(err, ErrStepUpNotFound):
+		respondWithError(w, http.StatusNotFound, "step_up_not_found", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "step_up_error", err.Error())
+	}
+}
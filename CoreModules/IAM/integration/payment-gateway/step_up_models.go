@@ -0,0 +1,167 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Estados do step-up de MFA de um pagamento
+const (
+	StepUpStatusPending  = "pending"  // Desafio emitido pelo serviço de identidade; pagamento suspenso
+	StepUpStatusVerified = "verified" // Desafio concluído; pagamento retomado
+	StepUpStatusFailed   = "failed"   // Desafio falhado ou cancelado pelo usuário; pagamento abortado
+	StepUpStatusExpired  = "expired"  // Sem conclusão dentro do prazo; pagamento abortado
+)
+
+// Resultados do desafio notificados pelo serviço de identidade
+const (
+	StepUpOutcomeCompleted = "completed"
+	StepUpOutcomeFailed    = "failed"
+	StepUpOutcomeCancelled = "cancelled"
+	StepUpOutcomeExpired   = "expired"
+)
+
+// Códigos de estado das respostas de pagamento sujeitas a step-up
+const (
+	StepUpRequiredStatusCode = "step_up_required"
+	StepUpApprovedStatusCode = "approved_step_up"
+	StepUpFailedStatusCode   = "step_up_failed"
+	StepUpExpiredStatusCode  = "step_up_expired"
+)
+
+// Cabeçalhos das notificações de conclusão enviadas pelo serviço de identidade
+const (
+	StepUpSignatureHeader = "X-Identity-Signature"
+	StepUpTimestampHeader = "X-Identity-Timestamp"
+)
+
+// Valores padrão do step-up de MFA
+const (
+	DefaultStepUpChallengeTTL     = 5 * time.Minute
+	DefaultStepUpRequiredMFALevel = "high"
+	DefaultStepUpHTTPTimeout      = 10 * time.Second
+	DefaultStepUpCallbackMaxSkew  = 5 * time.Minute
+	DefaultStepUpSweepInterval    = time.Minute
+	DefaultStepUpSweepBatch       = 100
+	stepUpStatusPathTemplate      = "/payments/%s/step-up"
+)
+
+// Erros do step-up de MFA
+var (
+	ErrStepUpNotFound         = errors.New("step-up do pagamento não encontrado")
+	ErrStepUpUnavailable      = errors.New("serviço de identidade indisponível para o step-up")
+	ErrStepUpRejected         = errors.New("desafio de step-up recusado pelo serviço de identidade")
+	ErrStepUpCallbackInvalid  = errors.New("notificação de step-up inválida")
+	ErrStepUpSignatureInvalid = errors.New("assinatura da notificação de step-up inválida")
+)
+
+// PaymentStepUp representa um pagamento suspenso à espera da conclusão de um desafio de MFA
+// O pedido, a resposta desafiada, a avaliação do dispositivo e a utilização para custos são
+// guardados para retomar o pagamento no ponto em que foi suspenso
+type PaymentStepUp struct {
+	StepUpID      string     `json:"step_up_id" db:"step_up_id"`
+	TenantID      string     `json:"tenant_id" db:"tenant_id"`
+	TransactionID string     `json:"transaction_id" db:"transaction_id"`
+	RequestID     string     `json:"request_id" db:"request_id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	ChallengeID   string     `json:"challenge_id" db:"challenge_id"`
+	TrustScore    int        `json:"trust_score" db:"trust_score"`
+	RiskLevel     string     `json:"risk_level" db:"risk_level"`
+	Status        string     `json:"status" db:"status"`
+	StatusReason  string     `json:"status_reason,omitempty" db:"status_reason"`
+	MFALevel      string     `json:"mfa_level,omitempty" db:"mfa_level"` // Nível de MFA atingido no desafio
+	Sandbox       bool       `json:"sandbox,omitempty" db:"sandbox"`     // Pagamento de um comerciante em modo sandbox
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+
+	Request  *PaymentRequest   `json:"-" db:"-"`
+	Response *PaymentResponse  `json:"response,omitempty" db:"-"`
+	Device   *DeviceAssessment `json:"-" db:"-"`
+	Usage    CostUsage         `json:"-" db:"-"`
+}
+
+// IsFinal indica se o step-up já foi resolvido
+func (s *PaymentStepUp) IsFinal() bool {
+	return s.Status != StepUpStatusPending
+}
+
+// StepUpChallengeRequest é o pedido de desafio enviado ao serviço de identidade
+type StepUpChallengeRequest struct {
+	TenantID         string                 `json:"tenant_id"`
+	UserID           string                 `json:"user_id"`
+	Reference        string                 `json:"reference"` // Identificador da transação
+	Reason           string                 `json:"reason"`
+	RequiredMFALevel string                 `json:"required_mfa_level"`
+	CallbackURL      string                 `json:"callback_url"`
+	ExpiresAt        time.Time              `json:"expires_at"`
+	Context          map[string]interface{} `json:"context,omitempty"`
+}
+
+// StepUpChallenge é o desafio emitido pelo serviço de identidade
+type StepUpChallenge struct {
+	ChallengeID     string    `json:"challenge_id"`
+	Method          string    `json:"method"`
+	Instructions    string    `json:"instructions,omitempty"`
+	VerificationURL string    `json:"verification_url,omitempty"`
+	MaxAttempts     int       `json:"max_attempts,omitempty"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// StepUpCallback é a notificação de conclusão do desafio enviada pelo serviço de identidade
+type StepUpCallback struct {
+	ChallengeID string    `json:"challenge_id"`
+	TenantID    string    `json:"tenant_id"`
+	UserID      string    `json:"user_id"`
+	Outcome     string    `json:"outcome"`
+	MFALevel    string    `json:"mfa_level,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Validate verifica a identificação e o resultado da notificação
+func (c *StepUpCallback) Validate() error {
+	if c.ChallengeID == "" || c.TenantID == "" {
+		return ErrStepUpCallbackInvalid
+	}
+	switch c.Outcome {
+	case StepUpOutcomeCompleted, StepUpOutcomeFailed, StepUpOutcomeCancelled, StepUpOutcomeExpired:
+		return nil
+	}
+	return ErrStepUpCallbackInvalid
+}
+
+// StepUpConfig contém as configurações do step-up de MFA durante o checkout
+type StepUpConfig struct {
+	// Endereço base da API do serviço de identidade e credencial do gateway
+	IdentityServiceURL string `json:"identity_service_url"`
+	APIKey             string `json:"-"`
+
+	// URL pública que recebe as notificações de conclusão dos desafios
+	CallbackURL string `json:"callback_url"`
+
+	// Segredo HMAC-SHA256 das notificações enviadas pelo serviço de identidade
+	CallbackSecret string `json:"-"`
+
+	// Desvio máximo entre o carimbo temporal assinado de uma notificação e o relógio local
+	CallbackMaxSkew time.Duration `json:"callback_max_skew"`
+
+	// Níveis de risco da avaliação que exigem step-up; vazio usa apenas o risco médio
+	RiskLevels []string `json:"risk_levels"`
+
+	// Nível de MFA exigido no desafio e prazo para o concluir
+	RequiredMFALevel string        `json:"required_mfa_level"`
+	ChallengeTTL     time.Duration `json:"challenge_ttl"`
+
+	// Intervalo e dimensão da varredura que aborta os pagamentos com desafios expirados
+	SweepInterval time.Duration `json:"sweep_interval"`
+	SweepBatch    int           `json:"sweep_batch"`
+
+	HTTPTimeout time.Duration `json:"http_timeout"`
+
+	// Resilience sobrepõe HTTPTimeout e define as novas tentativas das chamadas ao serviço de identidade
+	Resilience resilience.Policy `json:"resilience"`
+}
@@ -0,0 +1,227 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresPaymentStepUpStore implementa PaymentStepUpStore para PostgreSQL
+type PostgresPaymentStepUpStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresPaymentStepUpStore cria uma nova instância de PostgresPaymentStepUpStore
+func NewPostgresPaymentStepUpStore(db *sqlx.DB) *PostgresPaymentStepUpStore {
+	return &PostgresPaymentStepUpStore{db: db}
+}
+
+// paymentStepUpColumns são as colunas lidas de payment_gateway.payment_step_ups
+const paymentStepUpColumns = `
+	step_up_id, tenant_id, transaction_id, request_id, user_id, challenge_id, trust_score, risk_level,
+	status, status_reason, mfa_level, sandbox, expires_at, created_at, updated_at, completed_at,
+	request, response, device, usage
+`
+
+// dbPaymentStepUp é a representação de PaymentStepUp na base de dados
+type dbPaymentStepUp struct {
+	PaymentStepUp
+	RequestJSON  []byte `db:"request"`
+	ResponseJSON []byte `db:"response"`
+	DeviceJSON   []byte `db:"device"`
+	UsageJSON    []byte `db:"usage"`
+}
+
+// CreateStepUp grava o step-up; um novo step-up da transação substitui o anterior
+func (r *PostgresPaymentStepUpStore) CreateStepUp(ctx context.Context, stepUp *PaymentStepUp) error {
+	row, err := newDBPaymentStepUp(stepUp)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.payment_step_ups (
+			step_up_id, tenant_id, transaction_id, request_id, user_id, challenge_id, trust_score, risk_level,
+			status, status_reason, mfa_level, sandbox, expires_at, created_at, updated_at, completed_at,
+			request, response, device, usage
+		) VALUES (
+			:step_up_id, :tenant_id, :transaction_id, :request_id, :user_id, :challenge_id, :trust_score, :risk_level,
+			:status, :status_reason, :mfa_level, :sandbox, :expires_at, :created_at, :updated_at, :completed_at,
+			:request, :response, :device, :usage
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			step_up_id = EXCLUDED.step_up_id,
+			request_id = EXCLUDED.request_id,
+			challenge_id = EXCLUDED.challenge_id,
+			trust_score = EXCLUDED.trust_score,
+			risk_level = EXCLUDED.risk_level,
+			status = EXCLUDED.status,
+			status_reason = EXCLUDED.status_reason,
+			mfa_level = EXCLUDED.mfa_level,
+			sandbox = EXCLUDED.sandbox,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at,
+			completed_at = EXCLUDED.completed_at,
+			request = EXCLUDED.request,
+			response = EXCLUDED.response,
+			device = EXCLUDED.device,
+			usage = EXCLUDED.usage
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar step-up do pagamento: %w", err)
+	}
+
+	return nil
+}
+
+// GetStepUp recupera o step-up de uma transação do tenant, ou nil se não existir
+func (r *PostgresPaymentStepUpStore) GetStepUp(ctx context.Context, tenantID, transactionID string) (*PaymentStepUp, error) {
+	query := `SELECT ` + paymentStepUpColumns + ` FROM payment_gateway.payment_step_ups WHERE tenant_id = $1 AND transaction_id = $2`
+	return r.getStepUp(ctx, query, tenantID, transactionID)
+}
+
+// GetStepUpByChallenge recupera o step-up a partir do desafio do serviço de identidade, ou nil se não existir
+func (r *PostgresPaymentStepUpStore) GetStepUpByChallenge(ctx context.Context, challengeID string) (*PaymentStepUp, error) {
+	query := `SELECT ` + paymentStepUpColumns + ` FROM payment_gateway.payment_step_ups WHERE challenge_id = $1`
+	return r.getStepUp(ctx, query, challengeID)
+}
+
+// ResolveStepUp grava o resultado do step-up numa única instrução condicionada ao estado pendente
+func (r *PostgresPaymentStepUpStore) ResolveStepUp(ctx context.Context, stepUp *PaymentStepUp) (bool, error) {
+	row, err := newDBPaymentStepUp(stepUp)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE payment_gateway.payment_step_ups SET
+			status = :status,
+			status_reason = :status_reason,
+			mfa_level = :mfa_level,
+			updated_at = :updated_at,
+			completed_at = :completed_at,
+			response = :response
+		WHERE tenant_id = :tenant_id AND transaction_id = :transaction_id AND status = 'pending'
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, row)
+	if err != nil {
+		return false, fmt.Errorf("falha ao resolver step-up do pagamento: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("falha ao resolver step-up do pagamento: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// SaveStepUpResponse grava a resposta final do pagamento de um step-up resolvido
+func (r *PostgresPaymentStepUpStore) SaveStepUpResponse(ctx context.Context, stepUp *PaymentStepUp) error {
+	response, err := json.Marshal(stepUp.Response)
+	if err != nil {
+		return fmt.Errorf("falha ao codificar resposta do step-up: %w", err)
+	}
+
+	query := `
+		UPDATE payment_gateway.payment_step_ups
+		SET response = $3, completed_at = $4, updated_at = $5
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, stepUp.TenantID, stepUp.TransactionID, response, stepUp.CompletedAt, stepUp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("falha ao gravar resposta do step-up: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrStepUpNotFound
+	}
+
+	return nil
+}
+
+// ListExpiredStepUps lista até limit step-ups pendentes com prazo anterior a before
+func (r *PostgresPaymentStepUpStore) ListExpiredStepUps(ctx context.Context, before time.Time, limit int) ([]*PaymentStepUp, error) {
+	var rows []dbPaymentStepUp
+	query := `
+		SELECT ` + paymentStepUpColumns + `
+		FROM payment_gateway.payment_step_ups
+		WHERE status = 'pending' AND expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2
+	`
+	if err := r.db.SelectContext(ctx, &rows, query, before, limit); err != nil {
+		return nil, fmt.Errorf("falha ao listar step-ups expirados: %w", err)
+	}
+
+	stepUps := make([]*PaymentStepUp, 0, len(rows))
+	for i := range rows {
+		stepUp, err := rows[i].toPaymentStepUp()
+		if err != nil {
+			return nil, err
+		}
+		stepUps = append(stepUps, stepUp)
+	}
+	return stepUps, nil
+}
+
+// getStepUp executa uma consulta que retorna no máximo um step-up
+func (r *PostgresPaymentStepUpStore) getStepUp(ctx context.Context, query string, args ...interface{}) (*PaymentStepUp, error) {
+	var row dbPaymentStepUp
+	if err := r.db.GetContext(ctx, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar step-up do pagamento: %w", err)
+	}
+	return row.toPaymentStepUp()
+}
+
+// newDBPaymentStepUp codifica o pedido, a resposta, o dispositivo e a utilização do step-up
+func newDBPaymentStepUp(stepUp *PaymentStepUp) (*dbPaymentStepUp, error) {
+	row := &dbPaymentStepUp{PaymentStepUp: *stepUp}
+
+	var err error
+	if row.RequestJSON, err = json.Marshal(stepUp.Request); err != nil {
+		return nil, fmt.Errorf("falha ao codificar pedido do step-up: %w", err)
+	}
+	if row.ResponseJSON, err = json.Marshal(stepUp.Response); err != nil {
+		return nil, fmt.Errorf("falha ao codificar resposta do step-up: %w", err)
+	}
+	if row.DeviceJSON, err = json.Marshal(stepUp.Device); err != nil {
+		return nil, fmt.Errorf("falha ao codificar dispositivo do step-up: %w", err)
+	}
+	if row.UsageJSON, err = json.Marshal(stepUp.Usage); err != nil {
+		return nil, fmt.Errorf("falha ao codificar utilização do step-up: %w", err)
+	}
+	return row, nil
+}
+
+// toPaymentStepUp decodifica as colunas JSON do step-up
+func (row *dbPaymentStepUp) toPaymentStepUp() (*PaymentStepUp, error) {
+	stepUp := row.PaymentStepUp
+	for _, column := range []struct {
+		data   []byte
+		target interface{}
+		name   string
+	}{
+		{row.RequestJSON, &stepUp.Request, "pedido"},
+		{row.ResponseJSON, &stepUp.Response, "resposta"},
+		{row.DeviceJSON, &stepUp.Device, "dispositivo"},
+		{row.UsageJSON, &stepUp.Usage, "utilização"},
+	} {
+		if len(column.data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(column.data, column.target); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar %s do step-up: %w", column.name, err)
+		}
+	}
+	return &stepUp, nil
+}
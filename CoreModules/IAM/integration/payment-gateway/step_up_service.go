@@ -0,0 +1,455 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// StepUpService suspende os pagamentos de risco médio até o usuário concluir um desafio de MFA
+// no serviço de identidade e retoma ou aborta o pagamento quando o resultado é notificado
+type StepUpService struct {
+	config     StepUpConfig
+	store      PaymentStepUpStore
+	client     IdentityStepUpClient
+	connector  *BureauPaymentGatewayConnector
+	riskLevels map[string]bool
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewStepUpService cria o serviço de step-up; sem cliente fornecido usa a API HTTP do serviço de identidade
+func NewStepUpService(config StepUpConfig, store PaymentStepUpStore, client IdentityStepUpClient, connector *BureauPaymentGatewayConnector) (*StepUpService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-step-up",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.ChallengeTTL <= 0 {
+		config.ChallengeTTL = DefaultStepUpChallengeTTL
+	}
+	if config.RequiredMFALevel == "" {
+		config.RequiredMFALevel = DefaultStepUpRequiredMFALevel
+	}
+	if config.CallbackMaxSkew <= 0 {
+		config.CallbackMaxSkew = DefaultStepUpCallbackMaxSkew
+	}
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = DefaultStepUpSweepInterval
+	}
+	if config.SweepBatch <= 0 {
+		config.SweepBatch = DefaultStepUpSweepBatch
+	}
+	if len(config.RiskLevels) == 0 {
+		config.RiskLevels = []string{"medium"}
+	}
+
+	if client == nil {
+		if config.IdentityServiceURL == "" {
+			return nil, fmt.Errorf("endereço do serviço de identidade não configurado para o step-up")
+		}
+		client = NewHTTPIdentityStepUpClient(config)
+	}
+
+	riskLevels := make(map[string]bool)
+	for _, level := range config.RiskLevels {
+		riskLevels[level] = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StepUpService{
+		config:          config,
+		store:           store,
+		client:          client,
+		connector:       connector,
+		riskLevels:      riskLevels,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// SetClock substitui o relógio usado nos prazos dos desafios e na validação das notificações
+func (s *StepUpService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Start inicia a varredura que aborta os pagamentos com desafios expirados
+func (s *StepUpService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.SweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ExpirePending(s.ctx); err != nil {
+					s.logger.ErrorWithContext(s.ctx, "Falha na varredura de step-ups expirados", "error", err.Error())
+				}
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Varredura de step-ups de MFA iniciada", "interval", s.config.SweepInterval.String())
+}
+
+// Stop interrompe a varredura
+func (s *StepUpService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// Requires indica se o nível de risco da avaliação exige step-up
+func (s *StepUpService) Requires(riskLevel string) bool {
+	return s.riskLevels[riskLevel]
+}
+
+// Begin pede o desafio ao serviço de identidade e guarda o pagamento suspenso
+// A resposta desafiada é completada com os detalhes do desafio emitido
+func (s *StepUpService) Begin(ctx context.Context, req *PaymentRequest, response *PaymentResponse, device *DeviceAssessment, usage CostUsage) error {
+	ctx, span := s.tracer.StartSpan(ctx, "StepUpService.Begin")
+	defer span.End()
+
+	now := s.now()
+	expiresAt := now.Add(s.config.ChallengeTTL)
+
+	challenge, err := s.client.CreateChallenge(ctx, &StepUpChallengeRequest{
+		TenantID:         req.TenantID,
+		UserID:           req.UserID,
+		Reference:        req.TransactionID,
+		Reason:           "payment_risk_" + response.RiskLevel,
+		RequiredMFALevel: s.config.RequiredMFALevel,
+		CallbackURL:      s.config.CallbackURL,
+		ExpiresAt:        expiresAt,
+		Context: map[string]interface{}{
+			"amount":      req.Amount,
+			"currency":    req.Currency,
+			"merchant_id": req.MerchantID,
+			"trust_score": response.TrustScore,
+			"risk_level":  response.RiskLevel,
+		},
+	})
+	if err != nil {
+		s.metricsRecorder.CounterInc("payment_step_up_total", map[string]string{
+			"tenant_id": req.TenantID,
+			"result":    "challenge_error",
+		})
+		return err
+	}
+
+	// O prazo do serviço de identidade prevalece se for mais curto
+	if !challenge.ExpiresAt.IsZero() && challenge.ExpiresAt.Before(expiresAt) {
+		expiresAt = challenge.ExpiresAt
+	}
+
+	stepUp := &PaymentStepUp{
+		StepUpID:      uuid.New().String(),
+		TenantID:      req.TenantID,
+		TransactionID: req.TransactionID,
+		RequestID:     req.RequestID,
+		UserID:        req.UserID,
+		ChallengeID:   challenge.ChallengeID,
+		TrustScore:    response.TrustScore,
+		RiskLevel:     response.RiskLevel,
+		Status:        StepUpStatusPending,
+		Sandbox:       req.Sandbox,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Request:       req,
+		Device:        device,
+		Usage:         usage,
+	}
+
+	response.ChallengeRequired = true
+	response.ChallengeDetails = &ChallengeDetails{
+		ChallengeID:     challenge.ChallengeID,
+		ChallengeType:   "mfa_step_up",
+		ChallengeMethod: challenge.Method,
+		Instructions:    challenge.Instructions,
+		ExpirationTime:  expiresAt,
+		MaxRetries:      challenge.MaxAttempts,
+		VerificationURL: challenge.VerificationURL,
+		ChallengeMetadata: map[string]interface{}{
+			"step_up_id":         stepUp.StepUpID,
+			"required_mfa_level": s.config.RequiredMFALevel,
+			"status_url":         fmt.Sprintf(stepUpStatusPathTemplate, req.TransactionID),
+		},
+	}
+	stepUp.Response = response
+
+	if err := s.store.CreateStepUp(ctx, stepUp); err != nil {
+		// Sem registo não há como retomar o pagamento; o desafio é cancelado
+		if cancelErr := s.client.CancelChallenge(ctx, req.TenantID, challenge.ChallengeID, "payment_aborted"); cancelErr != nil {
+			s.logger.WarnWithContext(ctx, "Falha ao cancelar desafio de step-up",
+				"transaction_id", req.TransactionID,
+				"challenge_id", challenge.ChallengeID,
+				"error", cancelErr.Error())
+		}
+		return err
+	}
+
+	s.metricsRecorder.CounterInc("payment_step_up_total", map[string]string{
+		"tenant_id": req.TenantID,
+		"result":    "started",
+	})
+
+	s.logger.InfoWithContext(ctx, "Pagamento suspenso à espera de step-up de MFA",
+		"request_id", req.RequestID,
+		"transaction_id", req.TransactionID,
+		"challenge_id", challenge.ChallengeID,
+		"risk_level", response.RiskLevel,
+		"expires_at", expiresAt.Format(time.RFC3339))
+
+	return nil
+}
+
+// GetStepUp retorna o step-up de uma transação do tenant
+func (s *StepUpService) GetStepUp(ctx context.Context, tenantID, transactionID string) (*PaymentStepUp, error) {
+	stepUp, err := s.store.GetStepUp(ctx, tenantID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if stepUp == nil {
+		return nil, ErrStepUpNotFound
+	}
+	return stepUp, nil
+}
+
+// HandleCallback processa a notificação de conclusão do desafio enviada pelo serviço de identidade
+// O corpo é verificado com a assinatura HMAC antes de ser interpretado; notificações repetidas
+// de um step-up já resolvido retornam o resultado gravado
+func (s *StepUpService) HandleCallback(ctx context.Context, payload []byte, timestamp, signature string) (*PaymentStepUp, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "StepUpService.HandleCallback")
+	defer span.End()
+
+	if err := s.verifyCallbackSignature(payload, timestamp, signature); err != nil {
+		s.metricsRecorder.CounterInc("payment_step_up_callbacks_total", map[string]string{
+			"result": "invalid_signature",
+		})
+		return nil, err
+	}
+
+	var callback StepUpCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStepUpCallbackInvalid, err)
+	}
+	if err := callback.Validate(); err != nil {
+		return nil, err
+	}
+
+	stepUp, err := s.store.GetStepUpByChallenge(ctx, callback.ChallengeID)
+	if err != nil {
+		return nil, err
+	}
+	if stepUp == nil || stepUp.TenantID != callback.TenantID {
+		return nil, ErrStepUpNotFound
+	}
+	if callback.UserID != "" && callback.UserID != stepUp.UserID {
+		return nil, fmt.Errorf("%w: usuário do desafio não corresponde ao do pagamento", ErrStepUpCallbackInvalid)
+	}
+
+	s.metricsRecorder.CounterInc("payment_step_up_callbacks_total", map[string]string{
+		"result": callback.Outcome,
+	})
+
+	if stepUp.IsFinal() {
+		return stepUp, nil
+	}
+
+	status := StepUpStatusFailed
+	reason := callback.Reason
+	switch {
+	case callback.Outcome == StepUpOutcomeCompleted && s.now().After(stepUp.ExpiresAt):
+		// Conclusões recebidas depois do prazo não retomam o pagamento
+		status = StepUpStatusExpired
+		reason = "desafio concluído após o prazo"
+	case callback.Outcome == StepUpOutcomeCompleted:
+		status = StepUpStatusVerified
+	case callback.Outcome == StepUpOutcomeExpired:
+		status = StepUpStatusExpired
+	}
+	if reason == "" {
+		reason = "desafio " + callback.Outcome
+	}
+	stepUp.MFALevel = callback.MFALevel
+
+	return s.resolve(ctx, stepUp, status, reason)
+}
+
+// ExpirePending aborta os pagamentos cujos desafios expiraram sem notificação de conclusão
+func (s *StepUpService) ExpirePending(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "StepUpService.ExpirePending")
+	defer span.End()
+
+	expired, err := s.store.ListExpiredStepUps(ctx, s.now(), s.config.SweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	aborted := 0
+	for _, stepUp := range expired {
+		resolved, err := s.resolve(ctx, stepUp, StepUpStatusExpired, "prazo do desafio expirado")
+		if err != nil {
+			s.logger.ErrorWithContext(ctx, "Falha ao abortar pagamento com step-up expirado",
+				"transaction_id", stepUp.TransactionID,
+				"challenge_id", stepUp.ChallengeID,
+				"error", err.Error())
+			continue
+		}
+		if resolved.Status != StepUpStatusExpired {
+			continue
+		}
+		aborted++
+
+		// O usuário deixa de poder concluir o desafio de um pagamento já abortado
+		if err := s.client.CancelChallenge(ctx, stepUp.TenantID, stepUp.ChallengeID, "expired"); err != nil {
+			s.logger.WarnWithContext(ctx, "Falha ao cancelar desafio de step-up expirado",
+				"transaction_id", stepUp.TransactionID,
+				"challenge_id", stepUp.ChallengeID,
+				"error", err.Error())
+		}
+	}
+
+	return aborted, nil
+}
+
+// resolve grava o resultado do step-up e retoma ou aborta o pagamento suspenso
+// Se outro pedido resolver o step-up primeiro, retorna o resultado gravado por esse pedido
+func (s *StepUpService) resolve(ctx context.Context, stepUp *PaymentStepUp, status, reason string) (*PaymentStepUp, error) {
+	now := s.now()
+	stepUp.Status = status
+	stepUp.StatusReason = reason
+	stepUp.CompletedAt = &now
+	stepUp.UpdatedAt = now
+
+	won, err := s.store.ResolveStepUp(ctx, stepUp)
+	if err != nil {
+		return nil, err
+	}
+	if !won {
+		current, err := s.store.GetStepUp(ctx, stepUp.TenantID, stepUp.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			return nil, ErrStepUpNotFound
+		}
+		return current, nil
+	}
+
+	s.metricsRecorder.CounterInc("payment_step_up_total", map[string]string{
+		"tenant_id": stepUp.TenantID,
+		"result":    status,
+	})
+
+	stepUp.Response = s.completePayment(ctx, stepUp)
+	stepUp.UpdatedAt = s.now()
+	if err := s.store.SaveStepUpResponse(ctx, stepUp); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao gravar resposta final do step-up",
+			"transaction_id", stepUp.TransactionID,
+			"error", err.Error())
+	}
+
+	s.logger.InfoWithContext(ctx, "Step-up de MFA resolvido",
+		"transaction_id", stepUp.TransactionID,
+		"challenge_id", stepUp.ChallengeID,
+		"status", status,
+		"payment_status", stepUp.Response.Status)
+
+	return stepUp, nil
+}
+
+// completePayment finaliza o pagamento suspenso: aprovado se o desafio foi concluído, recusado caso contrário
+func (s *StepUpService) completePayment(ctx context.Context, stepUp *PaymentStepUp) *PaymentResponse {
+	req := stepUp.Request
+	// O pedido guardado não inclui o modo sandbox: o pagamento termina no ambiente em que começou
+	req.Sandbox = stepUp.Sandbox
+	response := &PaymentResponse{}
+	if stepUp.Response != nil {
+		*response = *stepUp.Response
+	}
+	response.ChallengeRequired = false
+	response.Timestamp = s.now()
+
+	switch stepUp.Status {
+	case StepUpStatusVerified:
+		response.Status = TransactionStatusApproved
+		response.StatusCode = StepUpApprovedStatusCode
+		response.StatusDescription = "Transação aprovada após step-up de MFA"
+		response.ApprovalCode = generateApprovalCode()
+		response.AuthorizationID = fmt.Sprintf("auth-%s", uuid.New().String()[0:8])
+	case StepUpStatusExpired:
+		response.Status = TransactionStatusDenied
+		response.StatusCode = StepUpExpiredStatusCode
+		response.StatusDescription = "Transação recusada: desafio de MFA não concluído dentro do prazo"
+	default:
+		response.Status = TransactionStatusDenied
+		response.StatusCode = StepUpFailedStatusCode
+		response.StatusDescription = "Transação recusada: desafio de MFA não concluído"
+	}
+
+	final := s.connector.finalizePayment(ctx, req, response, stepUp.Device, stepUp.Usage, s.now())
+
+	// Repetições do pedido passam a receber o resultado final
+	if s.connector.config.EnableCaching {
+		if final.Status == TransactionStatusError {
+			s.connector.transactionCache.Delete(req.RequestID)
+		} else {
+			s.connector.transactionCache.Store(req.RequestID, final)
+		}
+	}
+
+	if s.connector.async != nil {
+		s.connector.async.completeStepUp(ctx, req, final)
+	}
+
+	return final
+}
+
+// verifyCallbackSignature valida a assinatura HMAC-SHA256 de "<timestamp>.<payload>" e o desvio do carimbo temporal
+func (s *StepUpService) verifyCallbackSignature(payload []byte, timestamp, signature string) error {
+	if s.config.CallbackSecret == "" || signature == "" {
+		return ErrStepUpSignatureInvalid
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStepUpSignatureInvalid
+	}
+	skew := s.now().Sub(time.Unix(seconds, 0))
+	if skew > s.config.CallbackMaxSkew || skew < -s.config.CallbackMaxSkew {
+		return ErrStepUpSignatureInvalid
+	}
+	expected := signCallback(s.config.CallbackSecret, timestamp, payload)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrStepUpSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PaymentStepUpStore define a persistência dos pagamentos suspensos por step-up de MFA
+type PaymentStepUpStore interface {
+	// CreateStepUp grava um novo step-up pendente
+	CreateStepUp(ctx context.Context, stepUp *PaymentStepUp) error
+
+	// GetStepUp recupera o step-up de uma transação do tenant, ou nil se não existir
+	GetStepUp(ctx context.Context, tenantID, transactionID string) (*PaymentStepUp, error)
+
+	// GetStepUpByChallenge recupera o step-up a partir do desafio do serviço de identidade, ou nil se não existir
+	GetStepUpByChallenge(ctx context.Context, challengeID string) (*PaymentStepUp, error)
+
+	// ResolveStepUp grava o step-up apenas se o estado armazenado ainda for pendente
+	// Retorna false se outro pedido já o tiver resolvido
+	ResolveStepUp(ctx context.Context, stepUp *PaymentStepUp) (bool, error)
+
+	// SaveStepUpResponse grava a resposta final do pagamento de um step-up resolvido
+	SaveStepUpResponse(ctx context.Context, stepUp *PaymentStepUp) error
+
+	// ListExpiredStepUps lista até limit step-ups pendentes com prazo anterior a before, do mais antigo para o mais recente
+	ListExpiredStepUps(ctx context.Context, before time.Time, limit int) ([]*PaymentStepUp, error)
+}
+
+// InMemoryPaymentStepUpStore armazena os step-ups em memória
+type InMemoryPaymentStepUpStore struct {
+	stepUps map[string]*PaymentStepUp // Por tenant e transação
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryPaymentStepUpStore cria um novo armazenamento em memória
+func NewInMemoryPaymentStepUpStore() *InMemoryPaymentStepUpStore {
+	return &InMemoryPaymentStepUpStore{
+		stepUps: make(map[string]*PaymentStepUp),
+	}
+}
+
+// CreateStepUp grava uma cópia do step-up, substituindo um step-up anterior da mesma transação
+func (s *InMemoryPaymentStepUpStore) CreateStepUp(ctx context.Context, stepUp *PaymentStepUp) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stepUps[stepUp.TenantID+"\x00"+stepUp.TransactionID] = copyPaymentStepUp(stepUp)
+	return nil
+}
+
+// GetStepUp recupera uma cópia do step-up da transação
+func (s *InMemoryPaymentStepUpStore) GetStepUp(ctx context.Context, tenantID, transactionID string) (*PaymentStepUp, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stepUp, exists := s.stepUps[tenantID+"\x00"+transactionID]
+	if !exists {
+		return nil, nil
+	}
+	return copyPaymentStepUp(stepUp), nil
+}
+
+// GetStepUpByChallenge recupera uma cópia do step-up do desafio
+func (s *InMemoryPaymentStepUpStore) GetStepUpByChallenge(ctx context.Context, challengeID string) (*PaymentStepUp, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, stepUp := range s.stepUps {
+		if stepUp.ChallengeID == challengeID {
+			return copyPaymentStepUp(stepUp), nil
+		}
+	}
+	return nil, nil
+}
+
+// ResolveStepUp grava uma cópia do step-up se o armazenado ainda estiver pendente
+func (s *InMemoryPaymentStepUpStore) ResolveStepUp(ctx context.Context, stepUp *PaymentStepUp) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := stepUp.TenantID + "\x00" + stepUp.TransactionID
+	stored, exists := s.stepUps[key]
+	if !exists || stored.Status != StepUpStatusPending {
+		return false, nil
+	}
+	s.stepUps[key] = copyPaymentStepUp(stepUp)
+	return true, nil
+}
+
+// SaveStepUpResponse grava uma cópia da resposta final do step-up
+func (s *InMemoryPaymentStepUpStore) SaveStepUpResponse(ctx context.Context, stepUp *PaymentStepUp) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.stepUps[stepUp.TenantID+"\x00"+stepUp.TransactionID]
+	if !exists {
+		return ErrStepUpNotFound
+	}
+	if stepUp.Response != nil {
+		response := *stepUp.Response
+		stored.Response = &response
+	}
+	stored.CompletedAt = stepUp.CompletedAt
+	stored.UpdatedAt = stepUp.UpdatedAt
+	return nil
+}
+
+// ListExpiredStepUps lista cópias dos step-ups pendentes com prazo ultrapassado
+func (s *InMemoryPaymentStepUpStore) ListExpiredStepUps(ctx context.Context, before time.Time, limit int) ([]*PaymentStepUp, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	expired := make([]*PaymentStepUp, 0)
+	for _, stepUp := range s.stepUps {
+		if stepUp.Status == StepUpStatusPending && stepUp.ExpiresAt.Before(before) {
+			expired = append(expired, copyPaymentStepUp(stepUp))
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].ExpiresAt.Before(expired[j].ExpiresAt)
+	})
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+// copyPaymentStepUp copia o step-up e as estruturas que o serviço altera
+func copyPaymentStepUp(stepUp *PaymentStepUp) *PaymentStepUp {
+	copied := *stepUp
+	if stepUp.Request != nil {
+		request := *stepUp.Request
+		copied.Request = &request
+	}
+	if stepUp.Response != nil {
+		response := *stepUp.Response
+		copied.Response = &response
+	}
+	if stepUp.Device != nil {
+		device := *stepUp.Device
+		copied.Device = &device
+	}
+	return &copied
+}
@@ -0,0 +1,58 @@
+package paymentgateway
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// computeHash calcula o hash SHA-256 do registo e do hash anterior
+// Cada campo é prefixado pelo seu tamanho para que a concatenação não seja ambígua
+func (e *StoredCredentialAuditEntry) computeHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.TenantID,
+		strconv.FormatInt(e.Sequence, 10),
+		e.CredentialID,
+		e.Action,
+		e.Actor,
+		e.TransactionID,
+		e.Detail,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	var builder strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&builder, "%d:%s", len(field), field)
+	}
+	sum := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// nextStoredCredentialAuditEntry liga um novo registo ao último registo da cadeia do tenant
+func nextStoredCredentialAuditEntry(last *StoredCredentialAuditEntry, entry StoredCredentialAuditEntry) *StoredCredentialAuditEntry {
+	entry.Sequence = 1
+	entry.PrevHash = storedCredentialAuditGenesisHash
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+	}
+	entry.Hash = entry.computeHash()
+	return &entry
+}
+
+// VerifyStoredCredentialAuditChain verifica a cadeia de um tenant desde o primeiro registo
+// Retorna a sequência do primeiro registo inválido, ou zero quando a cadeia está íntegra
+func VerifyStoredCredentialAuditChain(entries []*StoredCredentialAuditEntry) int64 {
+	expectedPrev := storedCredentialAuditGenesisHash
+	for i, entry := range entries {
+		if entry.Sequence != int64(i+1) || entry.PrevHash != expectedPrev || entry.Hash != entry.computeHash() {
+			return int64(i + 1)
+		}
+		expectedPrev = entry.Hash
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// StoredCredentialHandler expõe às equipas de suporte os cartões armazenados, as transações CIT e MIT,
+// a revogação do consentimento e a evidência do consentimento e da utilização (PSD2, LGPD)
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type StoredCredentialHandler struct {
+	service *StoredCredentialService
+}
+
+// NewStoredCredentialHandler cria uma nova instância do StoredCredentialHandler
+func NewStoredCredentialHandler(service *StoredCredentialService) *StoredCredentialHandler {
+	return &StoredCredentialHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *StoredCredentialHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/stored-credentials", h.List).Methods(http.MethodGet)
+	router.HandleFunc("/support/stored-credentials", h.Create).Methods(http.MethodPost)
+	router.HandleFunc("/support/stored-credentials/{credentialId}", h.Get).Methods(http.MethodGet)
+	router.HandleFunc("/support/stored-credentials/{credentialId}/charges", h.Charge).Methods(http.MethodPost)
+	router.HandleFunc("/support/stored-credentials/{credentialId}/revoke", h.Revoke).Methods(http.MethodPost)
+	router.HandleFunc("/support/stored-credentials/{credentialId}/evidence", h.Evidence).Methods(http.MethodGet)
+}
+
+// List lista as credenciais do tenant filtradas por usuário, comerciante e status
+func (h *StoredCredentialHandler) List(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.service.List(r.Context(), StoredCredentialFilter{
+		TenantID:   r.Header.Get("X-Tenant-ID"),
+		UserID:     r.URL.Query().Get("user_id"),
+		MerchantID: r.URL.Query().Get("merchant_id"),
+		Status:     r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar credenciais armazenadas")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, credentials)
+}
+
+// Create processa a CIT inicial e armazena o cartão com o consentimento do titular
+func (h *StoredCredentialHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req StoredCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+	req.TenantID = r.Header.Get("X-Tenant-ID")
+
+	credential, err := h.service.StoreCardOnFile(r.Context(), req)
+	if err != nil {
+		respondWithStoredCredentialError(w, err, "Erro ao armazenar cartão")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, credential)
+}
+
+// Get retorna a credencial armazenada do tenant
+func (h *StoredCredentialHandler) Get(w http.ResponseWriter, r *http.Request) {
+	credential, err := h.service.Get(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["credentialId"])
+	if err != nil {
+		respondWithStoredCredentialError(w, err, "Erro ao recuperar credencial armazenada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, credential)
+}
+
+// Charge processa uma CIT ou MIT com a credencial armazenada
+func (h *StoredCredentialHandler) Charge(w http.ResponseWriter, r *http.Request) {
+	var charge StoredCredentialCharge
+	if err := json.NewDecoder(r.Body).Decode(&charge); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	result, err := h.service.Charge(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["credentialId"], charge)
+	if err != nil {
+		respondWithStoredCredentialError(w, err, "Erro ao processar transação com a credencial armazenada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// Revoke revoga o consentimento do titular em nome do operador autenticado
+func (h *StoredCredentialHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	credential, err := h.service.Revoke(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["credentialId"],
+		supportOperator(r), body.Reason)
+	if err != nil {
+		respondWithStoredCredentialError(w, err, "Erro ao revogar consentimento")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, credential)
+}
+
+// Evidence retorna o consentimento e a utilização da credencial com a verificação da cadeia de auditoria
+func (h *StoredCredentialHandler) Evidence(w http.ResponseWriter, r *http.Request) {
+	evidence, err := h.service.Evidence(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["credentialId"])
+	if err != nil {
+		respondWithStoredCredentialError(w, err, "Erro ao gerar evidência da credencial armazenada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, evidence)
+}
+
+// respondWithStoredCredentialError mapeia os erros das credenciais armazenadas para respostas HTTP
+func respondWithStoredCredentialError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrStoredCredentialNotFound):
+		respondWithError(w, http.StatusNotFound, "not_found", "Credencial armazenada não encontrada")
+	case errors.Is(err, ErrStoredCredentialNotConsented):
+		respondWithError(w, http.StatusForbidden, "not_consented", err.Error())
+	case errors.Is(err, ErrStoredCredentialPaymentFailed):
+		respondWithError(w, http.StatusPaymentRequired, "payment_failed", err.Error())
+	case errors.Is(err, ErrStoredCredentialInactive), errors.Is(err, ErrStoredCredentialStatusChanged):
+		respondWithError(w, http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, ErrStoredCredentialInvalid):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "internal_error", message)
+	}
+}
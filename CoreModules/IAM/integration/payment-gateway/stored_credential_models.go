@@ -0,0 +1,255 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/resilience"
+)
+
+// Iniciador das transações com credenciais armazenadas (card-on-file), segundo as regras das redes
+const (
+	StoredCredentialInitiatorCardholder = "cardholder" // Transação iniciada pelo titular (CIT)
+	StoredCredentialInitiatorMerchant   = "merchant"   // Transação iniciada pelo comerciante (MIT)
+)
+
+// Utilização da credencial indicada à rede na autorização
+const (
+	StoredCredentialUsageInitial    = "initial"    // CIT que armazena a credencial
+	StoredCredentialUsageSubsequent = "subsequent" // Transações seguintes com a credencial armazenada
+)
+
+// Motivos das transações com a credencial armazenada, autorizados pelo titular no consentimento
+const (
+	StoredCredentialReasonUnscheduled   = "unscheduled"    // Card-on-file sem calendário (UCOF)
+	StoredCredentialReasonRecurring     = "recurring"      // Cobrança periódica acordada com o titular
+	StoredCredentialReasonInstalment    = "instalment"     // Parcela de uma compra
+	StoredCredentialReasonResubmission  = "resubmission"   // Nova tentativa de uma cobrança recusada
+	StoredCredentialReasonDelayedCharge = "delayed_charge" // Cobrança adicional após o serviço
+	StoredCredentialReasonNoShow        = "no_show"        // Taxa de não comparência
+)
+
+// Estados de uma credencial armazenada
+const (
+	StoredCredentialStatusActive  = "active"
+	StoredCredentialStatusExpired = "expired" // Cartão fora da validade sem atualização do account updater
+	StoredCredentialStatusClosed  = "closed"  // Conta encerrada segundo o account updater
+	StoredCredentialStatusRevoked = "revoked" // Consentimento revogado pelo titular
+)
+
+// Desfechos do account updater das redes (Visa Account Updater, Mastercard ABU)
+const (
+	AccountUpdaterNoChange          = "no_change"
+	AccountUpdaterExpiryUpdated     = "expiry_updated"
+	AccountUpdaterAccountUpdated    = "account_updated" // Novo PAN, com a nova validade
+	AccountUpdaterAccountClosed     = "account_closed"
+	AccountUpdaterContactCardholder = "contact_cardholder"
+)
+
+// Ações registadas na cadeia de auditoria das credenciais armazenadas
+const (
+	StoredCredentialAuditConsentCaptured = "consent_captured"
+	StoredCredentialAuditInitialCIT      = "initial_cit"
+	StoredCredentialAuditCIT             = "cit"
+	StoredCredentialAuditMIT             = "mit"
+	StoredCredentialAuditDeclined        = "declined"
+	StoredCredentialAuditUsageRefused    = "usage_refused" // Utilização recusada pelo gateway antes da autorização
+	StoredCredentialAuditExpiryUpdated   = "expiry_updated"
+	StoredCredentialAuditAccountUpdated  = "account_updated"
+	StoredCredentialAuditAccountClosed   = "account_closed"
+	StoredCredentialAuditExpired         = "expired"
+	StoredCredentialAuditConsentRevoked  = "consent_revoked"
+)
+
+// Valores padrão das credenciais armazenadas
+const (
+	DefaultStoredCredentialMaintenanceInterval = 24 * time.Hour
+	DefaultStoredCredentialUpdaterLookahead    = 60 * 24 * time.Hour // Credenciais a expirar consultadas no account updater
+	DefaultAccountUpdaterTimeout               = 30 * time.Second
+
+	// storedCredentialAuditGenesisHash é o hash anterior do primeiro registo da cadeia de cada tenant
+	storedCredentialAuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	// storedCredentialAuditAttempts limita as novas tentativas quando outra réplica acrescenta à cadeia em simultâneo
+	storedCredentialAuditAttempts = 3
+)
+
+// storedCredentialReasons são os motivos aceites no consentimento e nas MIT
+var storedCredentialReasons = map[string]bool{
+	StoredCredentialReasonUnscheduled:   true,
+	StoredCredentialReasonRecurring:     true,
+	StoredCredentialReasonInstalment:    true,
+	StoredCredentialReasonResubmission:  true,
+	StoredCredentialReasonDelayedCharge: true,
+	StoredCredentialReasonNoShow:        true,
+}
+
+// Erros das credenciais armazenadas
+var (
+	ErrStoredCredentialNotFound      = errors.New("credencial armazenada não encontrada")
+	ErrStoredCredentialInvalid       = errors.New("pedido de credencial armazenada inválido")
+	ErrStoredCredentialInactive      = errors.New("credencial armazenada inativa")
+	ErrStoredCredentialStatusChanged = errors.New("estado da credencial armazenada alterado por outra operação")
+	ErrStoredCredentialNotConsented  = errors.New("utilização não coberta pelo consentimento do titular")
+	ErrStoredCredentialPaymentFailed = errors.New("transação com a credencial armazenada recusada")
+	ErrStoredCredentialAuditConflict = errors.New("sequência da cadeia de auditoria já registada")
+)
+
+// StoredCredentialConfig contém configurações das credenciais armazenadas
+type StoredCredentialConfig struct {
+	// Chave AES-256 (hex) que cifra os PAN armazenados; sem chave é usada uma chave efémera
+	KeyEncryptionKey string `json:"-"`
+
+	MaintenanceInterval time.Duration `json:"maintenance_interval"` // Periodicidade do account updater e da expiração
+	UpdaterLookahead    time.Duration `json:"updater_lookahead"`    // Antecedência da consulta das credenciais a expirar
+
+	// Serviço de account updater do adquirente; vazio desativa a consulta
+	AccountUpdaterURL string            `json:"account_updater_url"`
+	APIKey            string            `json:"-"`
+	HTTPTimeout       time.Duration     `json:"http_timeout"`
+	Resilience        resilience.Policy `json:"resilience"`
+}
+
+// StoredCredentialIndicator são os indicadores de card-on-file enviados à rede na autorização
+type StoredCredentialIndicator struct {
+	Initiator            string `json:"initiator"`
+	Usage                string `json:"usage"`
+	Reason               string `json:"reason,omitempty"`
+	NetworkTransactionID string `json:"network_transaction_id,omitempty"` // ID na rede da CIT inicial, exigido nas MIT
+}
+
+// StoredCredentialConsent é o acordo do titular para armazenar o cartão, capturado na CIT inicial.
+// Fica como evidência PSD2/LGPD da base para as transações seguintes
+type StoredCredentialConsent struct {
+	Reasons      []string  `json:"reasons"`              // Utilizações autorizadas pelo titular
+	TermsVersion string    `json:"terms_version"`        // Versão dos termos apresentados ao titular
+	Frequency    string    `json:"frequency,omitempty"`  // Periodicidade acordada das cobranças recorrentes (ex.: monthly)
+	MaxAmount    float64   `json:"max_amount,omitempty"` // Valor máximo por MIT na moeda da credencial (0 sem limite)
+	Channel      string    `json:"channel,omitempty"`    // Canal da captura (web, app, presencial)
+	CustomerIP   string    `json:"customer_ip,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	CapturedAt   time.Time `json:"captured_at"`
+}
+
+// StoredCredentialRequest é a CIT inicial que armazena o cartão com o consentimento do titular
+type StoredCredentialRequest struct {
+	TenantID    string                  `json:"-"`
+	MerchantID  string                  `json:"merchant_id"`
+	UserID      string                  `json:"user_id"`
+	RegionCode  string                  `json:"region_code"`
+	CardNumber  string                  `json:"card_number"`
+	ExpiryMonth int                     `json:"expiry_month"`
+	ExpiryYear  int                     `json:"expiry_year"`
+	Amount      float64                 `json:"amount"` // Valor da compra autorizada na CIT inicial
+	Currency    string                  `json:"currency"`
+	Description string                  `json:"description,omitempty"`
+	MFALevel    string                  `json:"mfa_level"`
+	Consent     StoredCredentialConsent `json:"consent"`
+}
+
+// StoredCredential é um cartão armazenado pelo comerciante. O PAN fica cifrado e só é usado nas
+// autorizações; as vistas expõem o PAN mascarado
+type StoredCredential struct {
+	ID                   string                  `json:"id" db:"id"`
+	TenantID             string                  `json:"tenant_id" db:"tenant_id"`
+	MerchantID           string                  `json:"merchant_id" db:"merchant_id"`
+	UserID               string                  `json:"user_id" db:"user_id"`
+	MaskedPAN            string                  `json:"masked_pan" db:"masked_pan"`
+	ExpiryMonth          int                     `json:"expiry_month" db:"expiry_month"`
+	ExpiryYear           int                     `json:"expiry_year" db:"expiry_year"`
+	RegionCode           string                  `json:"region_code" db:"region_code"`
+	Currency             string                  `json:"currency" db:"currency"`
+	Status               string                  `json:"status" db:"status"`
+	Consent              StoredCredentialConsent `json:"consent" db:"-"`
+	InitialTransactionID string                  `json:"initial_transaction_id" db:"initial_transaction_id"`
+	NetworkTransactionID string                  `json:"network_transaction_id" db:"network_transaction_id"`
+	CreatedAt            time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at" db:"updated_at"`
+	LastUsedAt           *time.Time              `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt            *time.Time              `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy            string                  `json:"revoked_by,omitempty" db:"revoked_by"`
+	EncryptedPAN         []byte                  `json:"-" db:"encrypted_pan"` // Apagado quando o consentimento é revogado ou a conta encerrada
+}
+
+// StoredCredentialFilter seleciona as credenciais listadas
+type StoredCredentialFilter struct {
+	TenantID   string
+	UserID     string
+	MerchantID string
+	Status     string
+}
+
+// StoredCredentialCharge é uma transação com a credencial armazenada, iniciada pelo titular ou pelo comerciante
+type StoredCredentialCharge struct {
+	TransactionID string  `json:"transaction_id,omitempty"` // Padrão: gerado pelo gateway
+	Initiator     string  `json:"initiator"`
+	Reason        string  `json:"reason,omitempty"` // Obrigatório nas MIT
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency,omitempty"` // Padrão: moeda da credencial
+	Description   string  `json:"description,omitempty"`
+	MFALevel      string  `json:"mfa_level,omitempty"` // Autenticação do titular nas CIT
+	CustomerIP    string  `json:"customer_ip,omitempty"`
+}
+
+// StoredCredentialChargeResult é o desfecho de uma transação com a credencial armazenada
+type StoredCredentialChargeResult struct {
+	TransactionID    string                     `json:"transaction_id"`
+	Status           string                     `json:"status"`
+	Reference        string                     `json:"reference,omitempty"`
+	StoredCredential *StoredCredentialIndicator `json:"stored_credential"`
+}
+
+// StoredCredentialAuditEntry é um registo da cadeia de auditoria do consentimento e da utilização das
+// credenciais do tenant, ligado ao anterior pelo hash para que a alteração ou remoção seja detetável
+type StoredCredentialAuditEntry struct {
+	TenantID      string    `json:"tenant_id" db:"tenant_id"`
+	Sequence      int64     `json:"sequence" db:"sequence"`
+	CredentialID  string    `json:"credential_id" db:"credential_id"`
+	Action        string    `json:"action" db:"action"`
+	Actor         string    `json:"actor" db:"actor"`
+	TransactionID string    `json:"transaction_id,omitempty" db:"transaction_id"`
+	Detail        string    `json:"detail,omitempty" db:"detail"`
+	OccurredAt    time.Time `json:"occurred_at" db:"occurred_at"`
+	PrevHash      string    `json:"prev_hash" db:"prev_hash"`
+	Hash          string    `json:"hash" db:"hash"`
+}
+
+// StoredCredentialEvidence reúne o consentimento e a utilização de uma credencial para pedidos de
+// evidência (PSD2, LGPD), com a verificação da cadeia de auditoria do tenant
+type StoredCredentialEvidence struct {
+	Credential       *StoredCredential             `json:"credential"`
+	Entries          []*StoredCredentialAuditEntry `json:"entries"`
+	ChainValid       bool                          `json:"chain_valid"`
+	BrokenAtSequence int64                         `json:"broken_at_sequence,omitempty"`
+	HeadHash         string                        `json:"head_hash,omitempty"`
+	GeneratedAt      time.Time                     `json:"generated_at"`
+}
+
+// AccountUpdaterInquiry é a consulta de uma credencial ao account updater
+type AccountUpdaterInquiry struct {
+	CredentialID string `json:"credential_id"`
+	MerchantID   string `json:"merchant_id"`
+	CardNumber   string `json:"card_number"`
+	ExpiryMonth  int    `json:"expiry_month"`
+	ExpiryYear   int    `json:"expiry_year"`
+}
+
+// AccountUpdaterResult é a resposta do account updater para uma credencial
+type AccountUpdaterResult struct {
+	CredentialID string `json:"credential_id"`
+	Outcome      string `json:"outcome"`
+	CardNumber   string `json:"card_number,omitempty"` // Novo PAN em account_updated
+	ExpiryMonth  int    `json:"expiry_month,omitempty"`
+	ExpiryYear   int    `json:"expiry_year,omitempty"`
+}
+
+// storedCredentialExpiresAt retorna o instante em que o cartão deixa de ser válido: o início do mês
+// seguinte ao da validade impressa
+func storedCredentialExpiresAt(month, year int) time.Time {
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// storedCredentialMerchantInitiated indica se o pagamento é uma MIT com credencial armazenada
+func storedCredentialMerchantInitiated(req *PaymentRequest) bool {
+	return req.StoredCredential != nil && req.StoredCredential.Initiator == StoredCredentialInitiatorMerchant
+}
@@ -0,0 +1,207 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresStoredCredentialStore implementa StoredCredentialStore para PostgreSQL
+type PostgresStoredCredentialStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresStoredCredentialStore cria uma nova instância de PostgresStoredCredentialStore
+func NewPostgresStoredCredentialStore(db *sqlx.DB) *PostgresStoredCredentialStore {
+	return &PostgresStoredCredentialStore{db: db}
+}
+
+// dbStoredCredential é a representação de StoredCredential na base de dados
+type dbStoredCredential struct {
+	StoredCredential
+	ConsentJSON []byte `db:"consent"`
+	FromStatus  string `db:"from_status"`
+}
+
+// storedCredentialColumns são as colunas lidas nas consultas de credenciais armazenadas
+const storedCredentialColumns = `id, tenant_id, merchant_id, user_id, masked_pan, expiry_month, expiry_year, region_code,
+	currency, status, consent, initial_transaction_id, network_transaction_id, encrypted_pan, created_at, updated_at,
+	last_used_at, revoked_at, revoked_by`
+
+// storedCredentialAuditColumns são as colunas lidas nas consultas da cadeia de auditoria
+const storedCredentialAuditColumns = `tenant_id, sequence, credential_id, action, actor, transaction_id, detail,
+	occurred_at, prev_hash, hash`
+
+// newDBStoredCredential codifica o consentimento em JSONB
+func newDBStoredCredential(credential *StoredCredential, fromStatus string) (*dbStoredCredential, error) {
+	row := &dbStoredCredential{StoredCredential: *credential, FromStatus: fromStatus}
+	var err error
+	if row.ConsentJSON, err = json.Marshal(credential.Consent); err != nil {
+		return nil, fmt.Errorf("falha ao codificar consentimento da credencial: %w", err)
+	}
+	return row, nil
+}
+
+// CreateStoredCredential grava uma nova credencial armazenada
+func (r *PostgresStoredCredentialStore) CreateStoredCredential(ctx context.Context, credential *StoredCredential) error {
+	row, err := newDBStoredCredential(credential, "")
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO payment_gateway.stored_credentials (
+			id, tenant_id, merchant_id, user_id, masked_pan, expiry_month, expiry_year, region_code,
+			currency, status, consent, initial_transaction_id, network_transaction_id, encrypted_pan, created_at, updated_at,
+			last_used_at, revoked_at, revoked_by
+		) VALUES (
+			:id, :tenant_id, :merchant_id, :user_id, :masked_pan, :expiry_month, :expiry_year, :region_code,
+			:currency, :status, :consent, :initial_transaction_id, :network_transaction_id, :encrypted_pan, :created_at, :updated_at,
+			:last_used_at, :revoked_at, :revoked_by
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar credencial armazenada: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStoredCredential grava o cartão, o estado e a utilização da credencial se o estado atual for fromStatus
+func (r *PostgresStoredCredentialStore) UpdateStoredCredential(ctx context.Context, credential *StoredCredential, fromStatus string) error {
+	row, err := newDBStoredCredential(credential, fromStatus)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE payment_gateway.stored_credentials SET
+			masked_pan = :masked_pan,
+			expiry_month = :expiry_month,
+			expiry_year = :expiry_year,
+			status = :status,
+			encrypted_pan = :encrypted_pan,
+			updated_at = :updated_at,
+			last_used_at = :last_used_at,
+			revoked_at = :revoked_at,
+			revoked_by = :revoked_by
+		WHERE id = :id AND status = :from_status
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, row)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar credencial armazenada: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar linhas afetadas: %w", err)
+	}
+	if affected == 0 {
+		if _, err := r.GetStoredCredential(ctx, credential.ID); err != nil {
+			return err
+		}
+		return ErrStoredCredentialStatusChanged
+	}
+
+	return nil
+}
+
+// GetStoredCredential recupera a credencial armazenada
+func (r *PostgresStoredCredentialStore) GetStoredCredential(ctx context.Context, credentialID string) (*StoredCredential, error) {
+	var row dbStoredCredential
+	query := `SELECT ` + storedCredentialColumns + ` FROM payment_gateway.stored_credentials WHERE id = $1`
+	if err := r.db.GetContext(ctx, &row, query, credentialID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStoredCredentialNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar credencial armazenada: %w", err)
+	}
+	return row.decode()
+}
+
+// ListStoredCredentials lista as credenciais do filtro, mais recentes primeiro
+func (r *PostgresStoredCredentialStore) ListStoredCredentials(ctx context.Context, filter StoredCredentialFilter) ([]*StoredCredential, error) {
+	var rows []dbStoredCredential
+	query := `SELECT ` + storedCredentialColumns + ` FROM payment_gateway.stored_credentials
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR user_id = $2) AND ($3 = '' OR merchant_id = $3)
+			AND ($4 = '' OR status = $4)
+		ORDER BY created_at DESC, id`
+	if err := r.db.SelectContext(ctx, &rows, query, filter.TenantID, filter.UserID, filter.MerchantID, filter.Status); err != nil {
+		return nil, fmt.Errorf("falha ao listar credenciais armazenadas: %w", err)
+	}
+
+	credentials := make([]*StoredCredential, 0, len(rows))
+	for i := range rows {
+		credential, err := rows[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// AppendStoredCredentialAudit acrescenta o registo à cadeia do tenant; a chave (tenant_id, sequence)
+// impede que duas réplicas liguem registos ao mesmo antecessor
+func (r *PostgresStoredCredentialStore) AppendStoredCredentialAudit(ctx context.Context, entry *StoredCredentialAuditEntry) error {
+	query := `
+		INSERT INTO payment_gateway.stored_credential_audit (
+			tenant_id, sequence, credential_id, action, actor, transaction_id, detail, occurred_at, prev_hash, hash
+		) VALUES (
+			:tenant_id, :sequence, :credential_id, :action, :actor, :transaction_id, :detail, :occurred_at, :prev_hash, :hash
+		)
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, entry); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrStoredCredentialAuditConflict
+		}
+		return fmt.Errorf("falha ao gravar registo de auditoria da credencial: %w", err)
+	}
+
+	return nil
+}
+
+// LastStoredCredentialAudit retorna o último registo da cadeia do tenant
+func (r *PostgresStoredCredentialStore) LastStoredCredentialAudit(ctx context.Context, tenantID string) (*StoredCredentialAuditEntry, error) {
+	var entry StoredCredentialAuditEntry
+	query := `SELECT ` + storedCredentialAuditColumns + ` FROM payment_gateway.stored_credential_audit
+		WHERE tenant_id = $1 ORDER BY sequence DESC LIMIT 1`
+	if err := r.db.GetContext(ctx, &entry, query, tenantID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar último registo de auditoria: %w", err)
+	}
+	return &entry, nil
+}
+
+// ListStoredCredentialAudit lista os registos por sequência
+func (r *PostgresStoredCredentialStore) ListStoredCredentialAudit(ctx context.Context, tenantID, credentialID string) ([]*StoredCredentialAuditEntry, error) {
+	entries := make([]*StoredCredentialAuditEntry, 0)
+	query := `SELECT ` + storedCredentialAuditColumns + ` FROM payment_gateway.stored_credential_audit
+		WHERE tenant_id = $1 AND ($2 = '' OR credential_id = $2)
+		ORDER BY sequence`
+	if err := r.db.SelectContext(ctx, &entries, query, tenantID, credentialID); err != nil {
+		return nil, fmt.Errorf("falha ao listar registos de auditoria das credenciais: %w", err)
+	}
+	return entries, nil
+}
+
+// decode descodifica o consentimento
+func (row *dbStoredCredential) decode() (*StoredCredential, error) {
+	credential := row.StoredCredential
+	if len(row.ConsentJSON) > 0 {
+		if err := json.Unmarshal(row.ConsentJSON, &credential.Consent); err != nil {
+			return nil, fmt.Errorf("falha ao descodificar consentimento da credencial: %w", err)
+		}
+	}
+	return &credential, nil
+}
@@ -0,0 +1,871 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// StoredCredentialProcessor processa as transações com as credenciais armazenadas; o
+// BureauPaymentGatewayConnector aplica as mesmas regras dos restantes pagamentos
+type StoredCredentialProcessor interface {
+	ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error)
+}
+
+// StoredCredentialService guarda os cartões armazenados (card-on-file) com o consentimento do titular,
+// valida cada utilização contra o consentimento e regista o consentimento e a utilização numa cadeia
+// de auditoria por tenant
+type StoredCredentialService struct {
+	config     StoredCredentialConfig
+	store      StoredCredentialStore
+	processor  StoredCredentialProcessor
+	updater    AccountUpdater
+	vault      cipher.AEAD // Cifra dos PAN armazenados em repouso
+	auditMutex sync.Mutex  // Serializa os registos da cadeia de auditoria nesta réplica
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewStoredCredentialService cria o serviço de credenciais armazenadas. O account updater é consultado
+// quando AccountUpdaterURL é configurado
+func NewStoredCredentialService(config StoredCredentialConfig, store StoredCredentialStore, processor StoredCredentialProcessor) (*StoredCredentialService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-stored-credentials",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.MaintenanceInterval <= 0 {
+		config.MaintenanceInterval = DefaultStoredCredentialMaintenanceInterval
+	}
+	if config.UpdaterLookahead <= 0 {
+		config.UpdaterLookahead = DefaultStoredCredentialUpdaterLookahead
+	}
+
+	logger := obsAdapter.Logger()
+	kek := make([]byte, 32)
+	if config.KeyEncryptionKey != "" {
+		decoded, err := hex.DecodeString(config.KeyEncryptionKey)
+		if err != nil || len(decoded) != 32 {
+			return nil, errors.New("chave de cifra das credenciais inválida: esperados 64 caracteres hexadecimais (AES-256)")
+		}
+		kek = decoded
+	} else {
+		if _, err := rand.Read(kek); err != nil {
+			return nil, fmt.Errorf("falha ao gerar chave de cifra das credenciais: %w", err)
+		}
+		logger.Warn("Chave de cifra das credenciais armazenadas não configurada, usando chave efémera")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	vault, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var updater AccountUpdater
+	if config.AccountUpdaterURL != "" {
+		updater = NewHTTPAccountUpdater(config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StoredCredentialService{
+		config:          config,
+		store:           store,
+		processor:       processor,
+		updater:         updater,
+		vault:           vault,
+		logger:          logger,
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start inicia a consulta periódica ao account updater e a expiração das credenciais fora da validade
+func (s *StoredCredentialService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.MaintenanceInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.RefreshAccountUpdates(s.ctx)
+				s.ExpireDue(s.ctx)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Manutenção das credenciais armazenadas iniciada", "interval", s.config.MaintenanceInterval.String())
+}
+
+// Stop interrompe a manutenção periódica
+func (s *StoredCredentialService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// StoreCardOnFile processa a CIT inicial, autenticada pelo titular, com o indicador de armazenamento e,
+// se aprovada, armazena o cartão com o consentimento e o ID na rede exigido nas MIT seguintes
+func (s *StoredCredentialService) StoreCardOnFile(ctx context.Context, req StoredCredentialRequest) (*StoredCredential, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "StoredCredentialService.StoreCardOnFile")
+	defer span.End()
+
+	if err := s.validate(req); err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	payment := &PaymentRequest{
+		RequestID:     uuid.New().String(),
+		TransactionID: fmt.Sprintf("tx-%s", uuid.New().String()),
+		TenantID:      req.TenantID,
+		RegionCode:    req.RegionCode,
+		UserID:        req.UserID,
+		MerchantID:    req.MerchantID,
+		PaymentMethod: PaymentMethodCard,
+		Amount:        roundAmount(req.Amount),
+		Currency:      currency,
+		Description:   req.Description,
+		MFALevel:      req.MFALevel,
+		PaymentDetails: map[string]interface{}{
+			"card_number":  req.CardNumber,
+			"expiry_month": req.ExpiryMonth,
+			"expiry_year":  req.ExpiryYear,
+		},
+		DeviceInfo: DeviceInfo{
+			IPAddress: req.Consent.CustomerIP,
+			UserAgent: req.Consent.UserAgent,
+		},
+		Metadata: map[string]interface{}{
+			"stored_credential": StoredCredentialUsageInitial,
+		},
+		StoredCredential: &StoredCredentialIndicator{
+			Initiator: StoredCredentialInitiatorCardholder,
+			Usage:     StoredCredentialUsageInitial,
+			Reason:    req.Consent.Reasons[0],
+		},
+		Timestamp: s.now().UTC(),
+	}
+	response, err := s.process(ctx, payment)
+	if err != nil {
+		span.RecordError(err)
+		s.recordStatus(req.RegionCode, "initial_declined")
+		return nil, fmt.Errorf("%w: %w", ErrStoredCredentialPaymentFailed, err)
+	}
+
+	// O ID na rede vem da autorização do PSP; sem ele, a referência da autorização identifica a CIT
+	networkTransactionID, _ := response.Metadata["network_transaction_id"].(string)
+	if networkTransactionID == "" {
+		networkTransactionID = response.AuthorizationID
+	}
+	if networkTransactionID == "" {
+		networkTransactionID = payment.TransactionID
+	}
+
+	now := s.now().UTC()
+	consent := req.Consent
+	consent.Reasons = append([]string(nil), consent.Reasons...)
+	consent.CapturedAt = now
+	credential := &StoredCredential{
+		ID:                   fmt.Sprintf("cof-%s", uuid.New().String()),
+		TenantID:             req.TenantID,
+		MerchantID:           req.MerchantID,
+		UserID:               req.UserID,
+		MaskedPAN:            posMaskPAN(req.CardNumber),
+		ExpiryMonth:          req.ExpiryMonth,
+		ExpiryYear:           req.ExpiryYear,
+		RegionCode:           req.RegionCode,
+		Currency:             currency,
+		Status:               StoredCredentialStatusActive,
+		Consent:              consent,
+		InitialTransactionID: payment.TransactionID,
+		NetworkTransactionID: networkTransactionID,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		LastUsedAt:           &now,
+	}
+	if credential.EncryptedPAN, err = s.seal(credential.ID, req.CardNumber); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("falha ao cifrar o cartão armazenado: %w", err)
+	}
+	if err := s.store.CreateStoredCredential(ctx, credential); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	maxAmount := "sem limite"
+	if consent.MaxAmount > 0 {
+		maxAmount = fmt.Sprintf("%.2f %s", consent.MaxAmount, credential.Currency)
+	}
+	if err := s.appendAudit(ctx, credential, StoredCredentialAuditConsentCaptured, req.UserID, payment.TransactionID,
+		fmt.Sprintf("termos %s; utilizações %s; periodicidade %s; máximo por MIT %s; canal %s; IP %s",
+			consent.TermsVersion, strings.Join(consent.Reasons, ","), consent.Frequency, maxAmount,
+			consent.Channel, consent.CustomerIP)); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, credential, StoredCredentialAuditInitialCIT, req.UserID, payment.TransactionID,
+		fmt.Sprintf("%.2f %s; cartão %s; ID na rede %s", payment.Amount, credential.Currency,
+			credential.MaskedPAN, networkTransactionID)); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	s.recordStatus(credential.RegionCode, "created")
+	s.logger.InfoWithContext(ctx, "Cartão armazenado com o consentimento do titular",
+		"tenant_id", credential.TenantID,
+		"credential_id", credential.ID,
+		"merchant_id", credential.MerchantID,
+		"masked_pan", credential.MaskedPAN,
+		"terms_version", consent.TermsVersion,
+		"reasons", strings.Join(consent.Reasons, ","),
+		"transaction_id", payment.TransactionID)
+	return redactStoredCredential(credential), nil
+}
+
+// validate verifica o cartão e o consentimento antes da CIT inicial
+func (s *StoredCredentialService) validate(req StoredCredentialRequest) error {
+	switch {
+	case req.TenantID == "" || req.MerchantID == "" || req.UserID == "":
+		return fmt.Errorf("%w: tenant, comerciante e usuário obrigatórios", ErrStoredCredentialInvalid)
+	case len(req.CardNumber) < 12 || len(req.CardNumber) > 19 || !posDigits(req.CardNumber):
+		return fmt.Errorf("%w: número do cartão inválido", ErrStoredCredentialInvalid)
+	case req.ExpiryMonth < 1 || req.ExpiryMonth > 12 || req.ExpiryYear < 2000:
+		return fmt.Errorf("%w: validade do cartão inválida", ErrStoredCredentialInvalid)
+	case !s.now().Before(storedCredentialExpiresAt(req.ExpiryMonth, req.ExpiryYear)):
+		return fmt.Errorf("%w: cartão fora da validade", ErrStoredCredentialInvalid)
+	case roundAmount(req.Amount) <= 0:
+		return fmt.Errorf("%w: valor da CIT inicial deve ser positivo", ErrStoredCredentialInvalid)
+	case len(strings.TrimSpace(req.Currency)) != 3:
+		return fmt.Errorf("%w: moeda deve ser um código ISO 4217", ErrStoredCredentialInvalid)
+	}
+
+	consent := req.Consent
+	switch {
+	case consent.TermsVersion == "":
+		return fmt.Errorf("%w: consentimento sem versão dos termos", ErrStoredCredentialInvalid)
+	case len(consent.Reasons) == 0:
+		return fmt.Errorf("%w: consentimento sem utilizações autorizadas", ErrStoredCredentialInvalid)
+	case consent.MaxAmount < 0:
+		return fmt.Errorf("%w: valor máximo do consentimento negativo", ErrStoredCredentialInvalid)
+	}
+	for _, reason := range consent.Reasons {
+		if !storedCredentialReasons[reason] {
+			return fmt.Errorf("%w: utilização %s desconhecida", ErrStoredCredentialInvalid, reason)
+		}
+	}
+	// As redes exigem que o acordo das cobranças recorrentes indique a periodicidade
+	if containsValue(consent.Reasons, StoredCredentialReasonRecurring) && consent.Frequency == "" {
+		return fmt.Errorf("%w: consentimento recorrente sem periodicidade", ErrStoredCredentialInvalid)
+	}
+	return nil
+}
+
+// Charge processa uma transação com a credencial armazenada. As CIT seguem o processamento com a
+// autenticação do titular; as MIT levam o ID na rede da CIT inicial e ficam fora da SCA, pelo que só
+// são aceites dentro do consentimento (motivo, moeda e valor máximo)
+func (s *StoredCredentialService) Charge(ctx context.Context, tenantID, credentialID string, charge StoredCredentialCharge) (*StoredCredentialChargeResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "StoredCredentialService.Charge")
+	defer span.End()
+
+	merchantInitiated := charge.Initiator == StoredCredentialInitiatorMerchant
+	switch {
+	case charge.Initiator != StoredCredentialInitiatorCardholder && !merchantInitiated:
+		return nil, fmt.Errorf("%w: iniciador deve ser %s ou %s", ErrStoredCredentialInvalid,
+			StoredCredentialInitiatorCardholder, StoredCredentialInitiatorMerchant)
+	case merchantInitiated && !storedCredentialReasons[charge.Reason]:
+		return nil, fmt.Errorf("%w: motivo da MIT inválido: %q", ErrStoredCredentialInvalid, charge.Reason)
+	case roundAmount(charge.Amount) <= 0:
+		return nil, fmt.Errorf("%w: valor deve ser positivo", ErrStoredCredentialInvalid)
+	}
+
+	credential, err := s.Get(ctx, tenantID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := credential.UserID
+	if merchantInitiated {
+		actor = credential.MerchantID
+	}
+	currency := strings.ToUpper(strings.TrimSpace(charge.Currency))
+	if currency == "" {
+		currency = credential.Currency
+	}
+	amount := roundAmount(charge.Amount)
+	label := storedCredentialUsageLabel(merchantInitiated)
+
+	var refusal error
+	switch {
+	case credential.Status != StoredCredentialStatusActive:
+		refusal = fmt.Errorf("%w: %s", ErrStoredCredentialInactive, credential.Status)
+	case merchantInitiated && !containsValue(credential.Consent.Reasons, charge.Reason):
+		refusal = fmt.Errorf("%w: motivo %s não autorizado", ErrStoredCredentialNotConsented, charge.Reason)
+	case merchantInitiated && currency != credential.Currency:
+		refusal = fmt.Errorf("%w: moeda %s diferente da acordada (%s)", ErrStoredCredentialNotConsented, currency, credential.Currency)
+	case merchantInitiated && credential.Consent.MaxAmount > 0 && amount > credential.Consent.MaxAmount:
+		refusal = fmt.Errorf("%w: %.2f %s acima do máximo acordado de %.2f", ErrStoredCredentialNotConsented,
+			amount, currency, credential.Consent.MaxAmount)
+	}
+	if refusal != nil {
+		span.RecordError(refusal)
+		s.recordStatus(credential.RegionCode, "usage_refused")
+		// Evento de segurança: utilização da credencial fora do consentimento ou com a credencial inativa
+		s.logger.WarnWithContext(ctx, "Utilização da credencial armazenada recusada",
+			"tenant_id", credential.TenantID,
+			"credential_id", credential.ID,
+			"merchant_id", credential.MerchantID,
+			"initiator", charge.Initiator,
+			"reason", charge.Reason,
+			"error", refusal.Error())
+		if err := s.appendAudit(ctx, credential, StoredCredentialAuditUsageRefused, actor, charge.TransactionID,
+			fmt.Sprintf("%s %s %.2f %s: %v", label, charge.Reason, amount, currency, refusal)); err != nil {
+			return nil, err
+		}
+		return nil, refusal
+	}
+
+	// O PAN é lido da credencial gravada, porque as vistas retornadas fora do serviço não o incluem
+	stored, err := s.store.GetStoredCredential(ctx, credential.ID)
+	if err != nil {
+		return nil, err
+	}
+	pan, err := s.open(stored)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	indicator := &StoredCredentialIndicator{
+		Initiator: charge.Initiator,
+		Usage:     StoredCredentialUsageSubsequent,
+		Reason:    charge.Reason,
+	}
+	if merchantInitiated {
+		indicator.NetworkTransactionID = credential.NetworkTransactionID
+	}
+	transactionID := charge.TransactionID
+	if transactionID == "" {
+		transactionID = fmt.Sprintf("tx-%s", uuid.New().String())
+	}
+	payment := &PaymentRequest{
+		RequestID:     uuid.New().String(),
+		TransactionID: transactionID,
+		TenantID:      credential.TenantID,
+		RegionCode:    credential.RegionCode,
+		UserID:        credential.UserID,
+		MerchantID:    credential.MerchantID,
+		PaymentMethod: PaymentMethodCard,
+		Amount:        amount,
+		Currency:      currency,
+		Description:   charge.Description,
+		MFALevel:      charge.MFALevel,
+		PaymentDetails: map[string]interface{}{
+			"card_number":  pan,
+			"expiry_month": credential.ExpiryMonth,
+			"expiry_year":  credential.ExpiryYear,
+		},
+		DeviceInfo: DeviceInfo{IPAddress: charge.CustomerIP},
+		Metadata: map[string]interface{}{
+			"stored_credential_id": credential.ID,
+			"merchant_initiated":   merchantInitiated,
+		},
+		RecurringPayment: charge.Reason == StoredCredentialReasonRecurring,
+		StoredCredential: indicator,
+		Timestamp:        s.now().UTC(),
+	}
+
+	response, paymentErr := s.process(ctx, payment)
+	action := StoredCredentialAuditCIT
+	if merchantInitiated {
+		action = StoredCredentialAuditMIT
+	}
+	detail := fmt.Sprintf("%s %.2f %s", charge.Reason, amount, currency)
+	status := strings.ToLower(label)
+	if paymentErr != nil {
+		span.RecordError(paymentErr)
+		action = StoredCredentialAuditDeclined
+		detail = fmt.Sprintf("%s %s %.2f %s: %v", label, charge.Reason, amount, currency, paymentErr)
+		status += "_declined"
+	} else {
+		detail += "; referência " + response.AuthorizationID
+		usedAt := s.now().UTC()
+		stored.LastUsedAt = &usedAt
+		stored.UpdatedAt = usedAt
+		if err := s.store.UpdateStoredCredential(ctx, stored, StoredCredentialStatusActive); err != nil {
+			// A transação já foi autorizada: a falha só atrasa a data de última utilização
+			s.logger.WarnWithContext(ctx, "Falha ao registar a utilização da credencial armazenada",
+				"tenant_id", credential.TenantID,
+				"credential_id", credential.ID,
+				"error", err.Error())
+		}
+	}
+	if err := s.appendAudit(ctx, credential, action, actor, transactionID, detail); err != nil {
+		return nil, err
+	}
+	s.recordStatus(credential.RegionCode, status)
+
+	if paymentErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStoredCredentialPaymentFailed, paymentErr)
+	}
+
+	s.logger.InfoWithContext(ctx, "Transação com a credencial armazenada aprovada",
+		"tenant_id", credential.TenantID,
+		"credential_id", credential.ID,
+		"transaction_id", transactionID,
+		"initiator", charge.Initiator,
+		"reason", charge.Reason,
+		"amount", amount,
+		"currency", currency)
+	return &StoredCredentialChargeResult{
+		TransactionID:    transactionID,
+		Status:           response.Status,
+		Reference:        response.AuthorizationID,
+		StoredCredential: indicator,
+	}, nil
+}
+
+// VerifyMerchantInitiated confirma que a MIT refere uma credencial ativa do tenant, do comerciante e do
+// titular e o ID na rede da sua CIT inicial, para que o indicador não seja usado para contornar a
+// autenticação do titular
+func (s *StoredCredentialService) VerifyMerchantInitiated(ctx context.Context, req *PaymentRequest) error {
+	credentialID, _ := req.Metadata["stored_credential_id"].(string)
+	credential, err := s.Get(ctx, req.TenantID, credentialID)
+	switch {
+	case err != nil:
+		return fmt.Errorf("MIT sem credencial armazenada: %w", err)
+	case credential.Status != StoredCredentialStatusActive:
+		return fmt.Errorf("MIT com credencial %s: %w", credential.Status, ErrStoredCredentialInactive)
+	case credential.MerchantID != req.MerchantID || credential.UserID != req.UserID ||
+		credential.NetworkTransactionID != req.StoredCredential.NetworkTransactionID:
+		// Evento de segurança: indicador MIT que não corresponde à credencial armazenada
+		s.logger.WarnWithContext(ctx, "MIT não corresponde à credencial armazenada",
+			"tenant_id", req.TenantID,
+			"credential_id", credentialID,
+			"merchant_id", req.MerchantID,
+			"transaction_id", req.TransactionID)
+		return fmt.Errorf("MIT não corresponde à credencial %s: %w", credentialID, ErrStoredCredentialNotConsented)
+	}
+	return nil
+}
+
+// Revoke revoga o consentimento a pedido do titular e apaga o PAN; a credencial deixa de aceitar transações
+func (s *StoredCredentialService) Revoke(ctx context.Context, tenantID, credentialID, revokedBy, reason string) (*StoredCredential, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "StoredCredentialService.Revoke")
+	defer span.End()
+
+	credential, err := s.Get(ctx, tenantID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status == StoredCredentialStatusRevoked {
+		return nil, fmt.Errorf("%w: %s", ErrStoredCredentialInactive, credential.Status)
+	}
+
+	fromStatus := credential.Status
+	now := s.now().UTC()
+	credential.Status = StoredCredentialStatusRevoked
+	credential.RevokedAt = &now
+	credential.RevokedBy = revokedBy
+	credential.UpdatedAt = now
+	credential.EncryptedPAN = nil
+	if err := s.store.UpdateStoredCredential(ctx, credential, fromStatus); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, credential, StoredCredentialAuditConsentRevoked, revokedBy, "", reason); err != nil {
+		return nil, err
+	}
+
+	s.recordStatus(credential.RegionCode, StoredCredentialStatusRevoked)
+	s.logger.InfoWithContext(ctx, "Consentimento da credencial armazenada revogado",
+		"tenant_id", credential.TenantID,
+		"credential_id", credential.ID,
+		"masked_pan", credential.MaskedPAN,
+		"revoked_by", revokedBy,
+		"reason", reason)
+	return credential, nil
+}
+
+// Get retorna a credencial do tenant, marcando-a como expirada se a validade do cartão terminou
+func (s *StoredCredentialService) Get(ctx context.Context, tenantID, credentialID string) (*StoredCredential, error) {
+	credential, err := s.store.GetStoredCredential(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if credential.TenantID != tenantID {
+		return nil, ErrStoredCredentialNotFound
+	}
+	if credential, err = s.expire(ctx, credential); err != nil {
+		return nil, err
+	}
+	return redactStoredCredential(credential), nil
+}
+
+// List lista as credenciais do filtro, mais recentes primeiro
+func (s *StoredCredentialService) List(ctx context.Context, filter StoredCredentialFilter) ([]*StoredCredential, error) {
+	credentials, err := s.store.ListStoredCredentials(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]*StoredCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		if credential, err = s.expire(ctx, credential); err != nil {
+			return nil, err
+		}
+		if filter.Status != "" && credential.Status != filter.Status {
+			continue
+		}
+		listed = append(listed, redactStoredCredential(credential))
+	}
+	return listed, nil
+}
+
+// Evidence retorna o consentimento e a utilização da credencial com a verificação da cadeia do tenant
+func (s *StoredCredentialService) Evidence(ctx context.Context, tenantID, credentialID string) (*StoredCredentialEvidence, error) {
+	credential, err := s.Get(ctx, tenantID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.store.ListStoredCredentialAudit(ctx, tenantID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	evidence := &StoredCredentialEvidence{
+		Credential:       credential,
+		Entries:          make([]*StoredCredentialAuditEntry, 0),
+		BrokenAtSequence: VerifyStoredCredentialAuditChain(chain),
+		GeneratedAt:      s.now().UTC(),
+	}
+	evidence.ChainValid = evidence.BrokenAtSequence == 0
+	if len(chain) > 0 {
+		evidence.HeadHash = chain[len(chain)-1].Hash
+	}
+	for _, entry := range chain {
+		if entry.CredentialID == credentialID {
+			evidence.Entries = append(evidence.Entries, entry)
+		}
+	}
+	if !evidence.ChainValid {
+		// Evento de segurança: a cadeia de auditoria foi alterada
+		s.logger.ErrorWithContext(ctx, "Cadeia de auditoria das credenciais armazenadas inválida",
+			"tenant_id", tenantID,
+			"broken_at_sequence", evidence.BrokenAtSequence)
+	}
+	return evidence, nil
+}
+
+// ExpireDue marca como expiradas as credenciais ativas fora da validade do cartão e retorna-as
+func (s *StoredCredentialService) ExpireDue(ctx context.Context) ([]*StoredCredential, error) {
+	credentials, err := s.store.ListStoredCredentials(ctx, StoredCredentialFilter{Status: StoredCredentialStatusActive})
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]*StoredCredential, 0)
+	for _, credential := range credentials {
+		updated, err := s.expire(ctx, credential)
+		if err != nil {
+			s.logger.ErrorWithContext(ctx, "Falha ao expirar credencial armazenada",
+				"tenant_id", credential.TenantID,
+				"credential_id", credential.ID,
+				"error", err.Error())
+			continue
+		}
+		if updated.Status == StoredCredentialStatusExpired {
+			expired = append(expired, redactStoredCredential(updated))
+		}
+	}
+	return expired, nil
+}
+
+// RefreshAccountUpdates consulta o account updater para as credenciais ativas que expiram dentro de
+// UpdaterLookahead e para as já expiradas, e aplica as atualizações. Uma nova validade reativa a
+// credencial expirada; uma conta encerrada apaga o PAN
+func (s *StoredCredentialService) RefreshAccountUpdates(ctx context.Context) ([]*StoredCredential, error) {
+	if s.updater == nil {
+		return nil, nil
+	}
+	ctx, span := s.tracer.StartSpan(ctx, "StoredCredentialService.RefreshAccountUpdates")
+	defer span.End()
+
+	candidates := make(map[string]*StoredCredential)
+	inquiries := make([]AccountUpdaterInquiry, 0)
+	horizon := s.now().Add(s.config.UpdaterLookahead)
+	for _, status := range []string{StoredCredentialStatusActive, StoredCredentialStatusExpired} {
+		credentials, err := s.store.ListStoredCredentials(ctx, StoredCredentialFilter{Status: status})
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		for _, credential := range credentials {
+			if horizon.Before(storedCredentialExpiresAt(credential.ExpiryMonth, credential.ExpiryYear)) {
+				continue
+			}
+			pan, err := s.open(credential)
+			if err != nil {
+				s.logger.ErrorWithContext(ctx, "Falha ao decifrar cartão armazenado para o account updater",
+					"tenant_id", credential.TenantID,
+					"credential_id", credential.ID,
+					"error", err.Error())
+				continue
+			}
+			candidates[credential.ID] = credential
+			inquiries = append(inquiries, AccountUpdaterInquiry{
+				CredentialID: credential.ID,
+				MerchantID:   credential.MerchantID,
+				CardNumber:   pan,
+				ExpiryMonth:  credential.ExpiryMonth,
+				ExpiryYear:   credential.ExpiryYear,
+			})
+		}
+	}
+	if len(inquiries) == 0 {
+		return nil, nil
+	}
+	sort.Slice(inquiries, func(i, j int) bool { return inquiries[i].CredentialID < inquiries[j].CredentialID })
+
+	results, err := s.updater.Inquire(ctx, inquiries)
+	if err != nil {
+		span.RecordError(err)
+		s.logger.WarnWithContext(ctx, "Falha na consulta ao account updater",
+			"credentials", len(inquiries),
+			"error", err.Error())
+		return nil, err
+	}
+
+	updated := make([]*StoredCredential, 0)
+	for _, result := range results {
+		credential, ok := candidates[result.CredentialID]
+		if !ok {
+			continue
+		}
+		applied, err := s.applyUpdate(ctx, credential, result)
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "Atualização do account updater não aplicada",
+				"tenant_id", credential.TenantID,
+				"credential_id", credential.ID,
+				"outcome", result.Outcome,
+				"error", err.Error())
+			continue
+		}
+		if applied {
+			updated = append(updated, redactStoredCredential(credential))
+		}
+	}
+	return updated, nil
+}
+
+// applyUpdate aplica uma resposta do account updater à credencial
+func (s *StoredCredentialService) applyUpdate(ctx context.Context, credential *StoredCredential, result AccountUpdaterResult) (bool, error) {
+	fromStatus := credential.Status
+	previous := fmt.Sprintf("%s %02d/%d", credential.MaskedPAN, credential.ExpiryMonth, credential.ExpiryYear)
+
+	var action string
+	switch result.Outcome {
+	case AccountUpdaterExpiryUpdated, AccountUpdaterAccountUpdated:
+		if result.ExpiryMonth < 1 || result.ExpiryMonth > 12 ||
+			!s.now().Before(storedCredentialExpiresAt(result.ExpiryMonth, result.ExpiryYear)) {
+			return false, errors.New("validade inválida recebida do account updater")
+		}
+		action = StoredCredentialAuditExpiryUpdated
+		if result.Outcome == AccountUpdaterAccountUpdated {
+			if len(result.CardNumber) < 12 || len(result.CardNumber) > 19 || !posDigits(result.CardNumber) {
+				return false, errors.New("número do cartão inválido recebido do account updater")
+			}
+			sealed, err := s.seal(credential.ID, result.CardNumber)
+			if err != nil {
+				return false, err
+			}
+			action = StoredCredentialAuditAccountUpdated
+			credential.EncryptedPAN = sealed
+			credential.MaskedPAN = posMaskPAN(result.CardNumber)
+		}
+		credential.ExpiryMonth = result.ExpiryMonth
+		credential.ExpiryYear = result.ExpiryYear
+		credential.Status = StoredCredentialStatusActive
+
+	case AccountUpdaterAccountClosed:
+		action = StoredCredentialAuditAccountClosed
+		credential.Status = StoredCredentialStatusClosed
+		credential.EncryptedPAN = nil
+
+	default:
+		// Sem alteração ou sem atualização disponível (contactar o titular)
+		return false, nil
+	}
+
+	credential.UpdatedAt = s.now().UTC()
+	if err := s.store.UpdateStoredCredential(ctx, credential, fromStatus); err != nil {
+		return false, err
+	}
+	detail := previous
+	if action != StoredCredentialAuditAccountClosed {
+		detail = fmt.Sprintf("%s → %s %02d/%d", previous, credential.MaskedPAN, credential.ExpiryMonth, credential.ExpiryYear)
+	}
+	if err := s.appendAudit(ctx, credential, action, "account_updater", "", detail); err != nil {
+		return false, err
+	}
+
+	s.recordStatus(credential.RegionCode, "account_updater_"+credential.Status)
+	s.logger.InfoWithContext(ctx, "Credencial armazenada atualizada pelo account updater",
+		"tenant_id", credential.TenantID,
+		"credential_id", credential.ID,
+		"outcome", result.Outcome,
+		"masked_pan", credential.MaskedPAN,
+		"status", credential.Status)
+	return true, nil
+}
+
+// expire marca a credencial ativa como expirada depois do fim do mês de validade do cartão
+func (s *StoredCredentialService) expire(ctx context.Context, credential *StoredCredential) (*StoredCredential, error) {
+	if credential.Status != StoredCredentialStatusActive ||
+		s.now().Before(storedCredentialExpiresAt(credential.ExpiryMonth, credential.ExpiryYear)) {
+		return credential, nil
+	}
+
+	credential.Status = StoredCredentialStatusExpired
+	credential.UpdatedAt = s.now().UTC()
+	if err := s.store.UpdateStoredCredential(ctx, credential, StoredCredentialStatusActive); err != nil {
+		if errors.Is(err, ErrStoredCredentialStatusChanged) {
+			// Outra operação alterou a credencial primeiro; retornar o estado gravado
+			return s.store.GetStoredCredential(ctx, credential.ID)
+		}
+		return nil, err
+	}
+	if err := s.appendAudit(ctx, credential, StoredCredentialAuditExpired, "gateway", "",
+		fmt.Sprintf("%s %02d/%d", credential.MaskedPAN, credential.ExpiryMonth, credential.ExpiryYear)); err != nil {
+		return nil, err
+	}
+	s.recordStatus(credential.RegionCode, StoredCredentialStatusExpired)
+	return credential, nil
+}
+
+// process submete a transação e trata como falha uma resposta não aprovada
+func (s *StoredCredentialService) process(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	response, err := s.processor.ProcessPayment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != TransactionStatusApproved {
+		return response, fmt.Errorf("pagamento %s: %s", response.Status, response.StatusDescription)
+	}
+	return response, nil
+}
+
+// appendAudit acrescenta um registo à cadeia de auditoria do tenant, tentando de novo quando outra
+// réplica acrescentou um registo em simultâneo
+func (s *StoredCredentialService) appendAudit(ctx context.Context, credential *StoredCredential, action, actor, transactionID, detail string) error {
+	s.auditMutex.Lock()
+	defer s.auditMutex.Unlock()
+
+	var err error
+	for attempt := 0; attempt < storedCredentialAuditAttempts; attempt++ {
+		var last *StoredCredentialAuditEntry
+		if last, err = s.store.LastStoredCredentialAudit(ctx, credential.TenantID); err != nil {
+			break
+		}
+		entry := nextStoredCredentialAuditEntry(last, StoredCredentialAuditEntry{
+			TenantID:      credential.TenantID,
+			CredentialID:  credential.ID,
+			Action:        action,
+			Actor:         actor,
+			TransactionID: transactionID,
+			Detail:        detail,
+			OccurredAt:    s.now().UTC(),
+		})
+		if err = s.store.AppendStoredCredentialAudit(ctx, entry); !errors.Is(err, ErrStoredCredentialAuditConflict) {
+			break
+		}
+	}
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao registar a credencial armazenada na cadeia de auditoria",
+			"tenant_id", credential.TenantID,
+			"credential_id", credential.ID,
+			"action", action,
+			"error", err.Error())
+		return fmt.Errorf("falha ao auditar credencial armazenada: %w", err)
+	}
+	return nil
+}
+
+// recordStatus contabiliza os eventos das credenciais armazenadas
+func (s *StoredCredentialService) recordStatus(market, status string) {
+	s.metricsRecorder.CounterInc("payment_gateway_stored_credentials", map[string]string{
+		"market": market,
+		"status": status,
+	})
+}
+
+// seal cifra o PAN com a chave de cifra do gateway, vinculado à credencial
+func (s *StoredCredentialService) seal(credentialID, pan string) ([]byte, error) {
+	nonce := make([]byte, s.vault.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.vault.Seal(nonce, nonce, []byte(pan), []byte(credentialID)), nil
+}
+
+// open decifra o PAN guardado por seal
+func (s *StoredCredentialService) open(credential *StoredCredential) (string, error) {
+	size := s.vault.NonceSize()
+	if len(credential.EncryptedPAN) < size {
+		return "", fmt.Errorf("%w: cartão da credencial %s apagado", ErrStoredCredentialInactive, credential.ID)
+	}
+	pan, err := s.vault.Open(nil, credential.EncryptedPAN[:size], credential.EncryptedPAN[size:], []byte(credential.ID))
+	if err != nil {
+		return "", fmt.Errorf("falha ao decifrar o cartão da credencial %s", credential.ID)
+	}
+	return string(pan), nil
+}
+
+// redactStoredCredential retira o PAN cifrado da credencial retornada fora do serviço
+func redactStoredCredential(credential *StoredCredential) *StoredCredential {
+	redacted := copyStoredCredential(credential)
+	redacted.EncryptedPAN = nil
+	return redacted
+}
+
+// storedCredentialUsageLabel identifica o tipo de transação nos registos de auditoria
+func storedCredentialUsageLabel(merchantInitiated bool) string {
+	if merchantInitiated {
+		return "MIT"
+	}
+	return "CIT"
+}
@@ -0,0 +1,426 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPSP encaminha os pedidos ao simulador e guarda os pedidos de autorização recebidos
+type recordingPSP struct {
+	simulator *PSPSimulator
+	mu        sync.Mutex
+	requests  []PSPAuthorizationRequest
+}
+
+func (p *recordingPSP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		body, _ := io.ReadAll(r.Body)
+		var request PSPAuthorizationRequest
+		if json.Unmarshal(body, &request) == nil {
+			p.mu.Lock()
+			p.requests = append(p.requests, request)
+			p.mu.Unlock()
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	p.simulator.ServeHTTP(w, r)
+}
+
+func (p *recordingPSP) last(t *testing.T) PSPAuthorizationRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.requests)
+	return p.requests[len(p.requests)-1]
+}
+
+func (p *recordingPSP) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// fakeStoredCredentialProcessor aprova os pagamentos e regista os pedidos
+type fakeStoredCredentialProcessor struct {
+	mu       sync.Mutex
+	requests []*PaymentRequest
+}
+
+func (p *fakeStoredCredentialProcessor) ProcessPayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	return &PaymentResponse{
+		TransactionID:   req.TransactionID,
+		Status:          TransactionStatusApproved,
+		AuthorizationID: "AUTH-" + req.TransactionID,
+	}, nil
+}
+
+func (p *fakeStoredCredentialProcessor) last(t *testing.T) *PaymentRequest {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	require.NotEmpty(t, p.requests)
+	return p.requests[len(p.requests)-1]
+}
+
+// fakeAccountUpdater responde às consultas com os desfechos configurados por credencial
+type fakeAccountUpdater struct {
+	results   map[string]AccountUpdaterResult
+	inquiries [][]AccountUpdaterInquiry
+}
+
+func (u *fakeAccountUpdater) Inquire(ctx context.Context, inquiries []AccountUpdaterInquiry) ([]AccountUpdaterResult, error) {
+	u.inquiries = append(u.inquiries, inquiries)
+	var results []AccountUpdaterResult
+	for _, inquiry := range inquiries {
+		if result, exists := u.results[inquiry.CredentialID]; exists {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func newTestStoredCredentialService(t *testing.T, processor StoredCredentialProcessor) (*StoredCredentialService, *InMemoryStoredCredentialStore) {
+	t.Helper()
+
+	store := NewInMemoryStoredCredentialStore()
+	service, err := NewStoredCredentialService(StoredCredentialConfig{
+		KeyEncryptionKey: strings.Repeat("ab", 32),
+	}, store, processor)
+	require.NoError(t, err)
+	return service, store
+}
+
+// newTestStoredCredentialConnector cria o conector com as autorizações registadas a caminho do simulador do PSP
+func newTestStoredCredentialConnector(t *testing.T) (*StoredCredentialService, *InMemoryStoredCredentialStore, *BureauPaymentGatewayConnector, *recordingPSP) {
+	t.Helper()
+
+	simulator, _ := newTestPSPSimulator(t, PSPSimulatorConfig{})
+	psp := &recordingPSP{simulator: simulator}
+	server := httptest.NewServer(psp)
+	t.Cleanup(server.Close)
+
+	connector, _ := newTestConnector(t)
+	pspService, _ := newTestPSPService(t, PSPConfig{Endpoint: server.URL})
+	connector.SetPSPService(pspService)
+	service, store := newTestStoredCredentialService(t, connector)
+	connector.SetStoredCredentialService(service)
+	return service, store, connector, psp
+}
+
+// testStoredCredentialRequest cria a CIT inicial de uma assinatura mensal de até 100 USD por MIT
+func testStoredCredentialRequest(cardNumber string, expiryMonth, expiryYear int) StoredCredentialRequest {
+	return StoredCredentialRequest{
+		TenantID:    "tenant-1",
+		MerchantID:  "merchant-1",
+		UserID:      "user-1",
+		RegionCode:  RegionUSA,
+		CardNumber:  cardNumber,
+		ExpiryMonth: expiryMonth,
+		ExpiryYear:  expiryYear,
+		Amount:      49.9,
+		Currency:    "usd",
+		MFALevel:    "high",
+		Consent: StoredCredentialConsent{
+			Reasons:      []string{StoredCredentialReasonRecurring, StoredCredentialReasonUnscheduled},
+			TermsVersion: "assinatura-2026.1",
+			Frequency:    "monthly",
+			MaxAmount:    100,
+			Channel:      "web",
+			CustomerIP:   "198.51.100.10",
+		},
+	}
+}
+
+func storedCredentialAuditActions(entries []*StoredCredentialAuditEntry) []string {
+	actions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func TestStoredCredentialValidate(t *testing.T) {
+	service, _ := newTestStoredCredentialService(t, &fakeStoredCredentialProcessor{})
+	service.now = func() time.Time { return time.Date(2026, 11, 18, 10, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		modify func(req *StoredCredentialRequest)
+	}{
+		{"sem titular", func(req *StoredCredentialRequest) { req.UserID = "" }},
+		{"cartão com letras", func(req *StoredCredentialRequest) { req.CardNumber = "4111a11111111111" }},
+		{"mês de validade inválido", func(req *StoredCredentialRequest) { req.ExpiryMonth = 13 }},
+		{"cartão fora da validade", func(req *StoredCredentialRequest) { req.ExpiryMonth, req.ExpiryYear = 10, 2026 }},
+		{"valor nulo", func(req *StoredCredentialRequest) { req.Amount = 0 }},
+		{"moeda inválida", func(req *StoredCredentialRequest) { req.Currency = "dólar" }},
+		{"sem versão dos termos", func(req *StoredCredentialRequest) { req.Consent.TermsVersion = "" }},
+		{"sem utilizações", func(req *StoredCredentialRequest) { req.Consent.Reasons = nil }},
+		{"utilização desconhecida", func(req *StoredCredentialRequest) { req.Consent.Reasons = []string{"marketing"} }},
+		{"recorrente sem periodicidade", func(req *StoredCredentialRequest) { req.Consent.Frequency = "" }},
+		{"máximo negativo", func(req *StoredCredentialRequest) { req.Consent.MaxAmount = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testStoredCredentialRequest("4111111111111111", 12, 2030)
+			tt.modify(&req)
+			assert.ErrorIs(t, service.validate(req), ErrStoredCredentialInvalid)
+		})
+	}
+
+	// O cartão é válido até ao fim do mês impresso
+	assert.NoError(t, service.validate(testStoredCredentialRequest("4111111111111111", 11, 2026)))
+}
+
+func TestStoredCredentialInitialCITAndMIT(t *testing.T) {
+	ctx := context.Background()
+	service, store, connector, psp := newTestStoredCredentialConnector(t)
+
+	// A CIT inicial é autenticada pelo titular e indica o armazenamento do cartão
+	credential, err := service.StoreCardOnFile(ctx, testStoredCredentialRequest("4111111111111111", 12, 2030))
+	require.NoError(t, err)
+	assert.Equal(t, StoredCredentialStatusActive, credential.Status)
+	assert.Equal(t, "411111******1111", credential.MaskedPAN)
+	assert.Equal(t, "USD", credential.Currency)
+	assert.Empty(t, credential.EncryptedPAN)
+	require.True(t, strings.HasPrefix(credential.NetworkTransactionID, "NTID-"), credential.NetworkTransactionID)
+	assert.Equal(t, &StoredCredentialIndicator{
+		Initiator: StoredCredentialInitiatorCardholder,
+		Usage:     StoredCredentialUsageInitial,
+		Reason:    StoredCredentialReasonRecurring,
+	}, psp.last(t).StoredCredential)
+
+	// O PAN fica cifrado no armazenamento
+	stored, err := store.GetStoredCredential(ctx, credential.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, stored.EncryptedPAN)
+	assert.NotContains(t, string(stored.EncryptedPAN), "4111111111111111")
+
+	// A MIT segue sem o titular presente, com o ID na rede da CIT inicial
+	result, err := service.Charge(ctx, "tenant-1", credential.ID, StoredCredentialCharge{
+		Initiator: StoredCredentialInitiatorMerchant,
+		Reason:    StoredCredentialReasonRecurring,
+		Amount:    49.9,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TransactionStatusApproved, result.Status)
+	require.NotEmpty(t, result.Reference)
+	request := psp.last(t)
+	assert.Equal(t, result.TransactionID, request.TransactionID)
+	assert.Equal(t, "4111111111111111", request.CardNumber)
+	assert.Equal(t, &StoredCredentialIndicator{
+		Initiator:            StoredCredentialInitiatorMerchant,
+		Usage:                StoredCredentialUsageSubsequent,
+		Reason:               StoredCredentialReasonRecurring,
+		NetworkTransactionID: credential.NetworkTransactionID,
+	}, request.StoredCredential)
+
+	// As MIT fora do consentimento são recusadas antes de chegar ao PSP
+	sent := psp.count()
+	for name, charge := range map[string]StoredCredentialCharge{
+		"motivo":       {Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonNoShow, Amount: 20},
+		"valor máximo": {Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonUnscheduled, Amount: 150},
+		"outra moeda":  {Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonRecurring, Amount: 20, Currency: "EUR"},
+	} {
+		_, err := service.Charge(ctx, "tenant-1", credential.ID, charge)
+		assert.ErrorIs(t, err, ErrStoredCredentialNotConsented, name)
+	}
+	assert.Equal(t, sent, psp.count())
+
+	// Outro tenant não vê a credencial
+	_, err = service.Charge(ctx, "tenant-2", credential.ID, StoredCredentialCharge{
+		Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonRecurring, Amount: 20,
+	})
+	assert.ErrorIs(t, err, ErrStoredCredentialNotFound)
+
+	// O indicador MIT não dispensa a autenticação sem uma credencial armazenada correspondente
+	forged := testPSPRequest("mit-forjada", "4111111111111111", 20)
+	forged.StoredCredential = &StoredCredentialIndicator{
+		Initiator:            StoredCredentialInitiatorMerchant,
+		Usage:                StoredCredentialUsageSubsequent,
+		Reason:               StoredCredentialReasonRecurring,
+		NetworkTransactionID: "NTID-FORJADO",
+	}
+	forged.Metadata = map[string]interface{}{"stored_credential_id": credential.ID}
+	response, err := connector.ProcessPayment(ctx, forged)
+	require.NoError(t, err)
+	assert.Equal(t, "credencial_armazenada_invalida", response.StatusCode)
+	assert.Equal(t, sent, psp.count())
+
+	// Depois de revogado o consentimento, o cartão deixa de aceitar transações
+	router := mux.NewRouter()
+	NewStoredCredentialHandler(service).RegisterRoutes(router)
+	rec := serveSupport(router, http.MethodPost, "/support/stored-credentials/"+credential.ID+"/revoke")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	_, err = service.Charge(ctx, "tenant-1", credential.ID, StoredCredentialCharge{
+		Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonRecurring, Amount: 49.9,
+	})
+	assert.ErrorIs(t, err, ErrStoredCredentialInactive)
+	stored, err = store.GetStoredCredential(ctx, credential.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.EncryptedPAN)
+
+	// A evidência reúne o consentimento e cada utilização, com a cadeia de auditoria íntegra
+	rec = serveSupport(router, http.MethodGet, "/support/stored-credentials/"+credential.ID+"/evidence")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var evidence StoredCredentialEvidence
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &evidence))
+	assert.True(t, evidence.ChainValid)
+	assert.Equal(t, StoredCredentialStatusRevoked, evidence.Credential.Status)
+	assert.Equal(t, "ops-1", evidence.Credential.RevokedBy)
+	assert.Equal(t, []string{
+		StoredCredentialAuditConsentCaptured, StoredCredentialAuditInitialCIT, StoredCredentialAuditMIT,
+		StoredCredentialAuditUsageRefused, StoredCredentialAuditUsageRefused, StoredCredentialAuditUsageRefused,
+		StoredCredentialAuditConsentRevoked, StoredCredentialAuditUsageRefused,
+	}, storedCredentialAuditActions(evidence.Entries))
+	assert.Contains(t, evidence.Entries[0].Detail, "assinatura-2026.1")
+	assert.NotContains(t, rec.Body.String(), "4111111111111111")
+
+	// Um registo alterado quebra a cadeia a partir dessa sequência
+	store.audit["tenant-1"][2].Detail = "alterado"
+	tampered, err := service.Evidence(ctx, "tenant-1", credential.ID)
+	require.NoError(t, err)
+	assert.False(t, tampered.ChainValid)
+	assert.Equal(t, int64(3), tampered.BrokenAtSequence)
+}
+
+func TestStoredCredentialMITOutOfSCAScope(t *testing.T) {
+	mit := testRiskRequest(RegionEU, "EUR", 250)
+	mit.StoredCredential = &StoredCredentialIndicator{
+		Initiator:            StoredCredentialInitiatorMerchant,
+		Usage:                StoredCredentialUsageSubsequent,
+		Reason:               StoredCredentialReasonRecurring,
+		NetworkTransactionID: "NTID-1",
+	}
+	assert.False(t, scaInScope(mit))
+	assert.False(t, scaRequired(mit))
+
+	cit := testRiskRequest(RegionEU, "EUR", 250)
+	cit.StoredCredential = &StoredCredentialIndicator{
+		Initiator: StoredCredentialInitiatorCardholder,
+		Usage:     StoredCredentialUsageSubsequent,
+	}
+	assert.True(t, scaInScope(cit))
+	assert.True(t, scaRequired(cit))
+
+	// O simulador não desafia as MIT e recusa as que não referem a CIT inicial
+	simulator, _ := newTestPSPSimulator(t, PSPSimulatorConfig{})
+	outcome := simulator.decide(PSPAuthorizationRequest{
+		TransactionID:    "tx-mit",
+		CardNumber:       PSPSimulatorCard3DSChallenge,
+		Amount:           250,
+		StoredCredential: mit.StoredCredential,
+	})
+	assert.Equal(t, PSPStatusApproved, outcome.response.Status)
+	assert.NotEmpty(t, outcome.response.NetworkTransactionID)
+
+	outcome = simulator.decide(PSPAuthorizationRequest{
+		TransactionID:    "tx-mit-sem-cit",
+		CardNumber:       "4111111111111111",
+		Amount:           250,
+		StoredCredential: &StoredCredentialIndicator{Initiator: StoredCredentialInitiatorMerchant, Usage: StoredCredentialUsageSubsequent},
+	})
+	assert.Equal(t, PSPStatusDeclined, outcome.response.Status)
+	assert.Equal(t, "57", outcome.response.ResponseCode)
+}
+
+func TestStoredCredentialAccountUpdater(t *testing.T) {
+	ctx := context.Background()
+	processor := &fakeStoredCredentialProcessor{}
+	service, _ := newTestStoredCredentialService(t, processor)
+	clock := time.Date(2026, 11, 18, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	updater := &fakeAccountUpdater{results: make(map[string]AccountUpdaterResult)}
+	service.updater = updater
+
+	storeCard := func(cardNumber string, expiryMonth, expiryYear int) *StoredCredential {
+		credential, err := service.StoreCardOnFile(ctx, testStoredCredentialRequest(cardNumber, expiryMonth, expiryYear))
+		require.NoError(t, err)
+		return credential
+	}
+	renewed := storeCard("4111111111111111", 12, 2026)
+	closed := storeCard("4012888888881881", 12, 2026)
+	lapsing := storeCard("4242424242424242", 11, 2026)
+	current := storeCard("5555555555554444", 12, 2030)
+
+	// Sem ID na rede do PSP, a referência da autorização identifica a CIT inicial
+	assert.Equal(t, "AUTH-"+current.InitialTransactionID, current.NetworkTransactionID)
+
+	updater.results[renewed.ID] = AccountUpdaterResult{CredentialID: renewed.ID, Outcome: AccountUpdaterExpiryUpdated, ExpiryMonth: 12, ExpiryYear: 2029}
+	updater.results[closed.ID] = AccountUpdaterResult{CredentialID: closed.ID, Outcome: AccountUpdaterAccountClosed}
+	updater.results[lapsing.ID] = AccountUpdaterResult{CredentialID: lapsing.ID, Outcome: AccountUpdaterContactCardholder}
+
+	// Só as credenciais a expirar dentro da janela são consultadas
+	updated, err := service.RefreshAccountUpdates(ctx)
+	require.NoError(t, err)
+	assert.Len(t, updated, 2)
+	require.Len(t, updater.inquiries, 1)
+	inquired := make([]string, 0)
+	for _, inquiry := range updater.inquiries[0] {
+		inquired = append(inquired, inquiry.CredentialID)
+	}
+	assert.ElementsMatch(t, []string{renewed.ID, closed.ID, lapsing.ID}, inquired)
+	assert.Contains(t, updater.inquiries[0], AccountUpdaterInquiry{
+		CredentialID: renewed.ID, MerchantID: "merchant-1", CardNumber: "4111111111111111", ExpiryMonth: 12, ExpiryYear: 2026,
+	})
+
+	got, err := service.Get(ctx, "tenant-1", renewed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StoredCredentialStatusActive, got.Status)
+	assert.Equal(t, 2029, got.ExpiryYear)
+	got, err = service.Get(ctx, "tenant-1", closed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StoredCredentialStatusClosed, got.Status)
+	got, err = service.Get(ctx, "tenant-1", current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StoredCredentialStatusActive, got.Status)
+
+	// Sem atualização, o cartão expira no fim do mês de validade e deixa de aceitar MIT
+	clock = time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	expired, err := service.ExpireDue(ctx)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, lapsing.ID, expired[0].ID)
+	mit := StoredCredentialCharge{Initiator: StoredCredentialInitiatorMerchant, Reason: StoredCredentialReasonRecurring, Amount: 49.9}
+	_, err = service.Charge(ctx, "tenant-1", lapsing.ID, mit)
+	assert.ErrorIs(t, err, ErrStoredCredentialInactive)
+	_, err = service.Charge(ctx, "tenant-1", closed.ID, mit)
+	assert.ErrorIs(t, err, ErrStoredCredentialInactive)
+
+	// Um novo cartão do emissor reativa a credencial e passa a ser usado nas MIT
+	updater.results[lapsing.ID] = AccountUpdaterResult{
+		CredentialID: lapsing.ID, Outcome: AccountUpdaterAccountUpdated,
+		CardNumber: "4000056655665556", ExpiryMonth: 10, ExpiryYear: 2030,
+	}
+	_, err = service.RefreshAccountUpdates(ctx)
+	require.NoError(t, err)
+	got, err = service.Get(ctx, "tenant-1", lapsing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StoredCredentialStatusActive, got.Status)
+	assert.Equal(t, "400005******5556", got.MaskedPAN)
+	_, err = service.Charge(ctx, "tenant-1", lapsing.ID, mit)
+	require.NoError(t, err)
+	assert.Equal(t, "4000056655665556", processor.last(t).PaymentDetails["card_number"])
+
+	evidence, err := service.Evidence(ctx, "tenant-1", lapsing.ID)
+	require.NoError(t, err)
+	assert.True(t, evidence.ChainValid)
+	assert.Equal(t, []string{
+		StoredCredentialAuditConsentCaptured, StoredCredentialAuditInitialCIT, StoredCredentialAuditExpired,
+		StoredCredentialAuditUsageRefused, StoredCredentialAuditAccountUpdated, StoredCredentialAuditMIT,
+	}, storedCredentialAuditActions(evidence.Entries))
+}
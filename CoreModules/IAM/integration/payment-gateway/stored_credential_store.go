@@ -0,0 +1,167 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// StoredCredentialStore define a persistência das credenciais armazenadas e da cadeia de auditoria
+type StoredCredentialStore interface {
+	// CreateStoredCredential grava uma nova credencial armazenada
+	CreateStoredCredential(ctx context.Context, credential *StoredCredential) error
+
+	// UpdateStoredCredential grava a credencial se o estado atual for fromStatus; retorna
+	// ErrStoredCredentialStatusChanged quando o estado já mudou
+	UpdateStoredCredential(ctx context.Context, credential *StoredCredential, fromStatus string) error
+
+	// GetStoredCredential recupera a credencial; retorna ErrStoredCredentialNotFound quando não existe
+	GetStoredCredential(ctx context.Context, credentialID string) (*StoredCredential, error)
+
+	// ListStoredCredentials lista as credenciais do filtro, mais recentes primeiro
+	ListStoredCredentials(ctx context.Context, filter StoredCredentialFilter) ([]*StoredCredential, error)
+
+	// AppendStoredCredentialAudit acrescenta o registo à cadeia do tenant; retorna
+	// ErrStoredCredentialAuditConflict quando a sequência já foi registada
+	AppendStoredCredentialAudit(ctx context.Context, entry *StoredCredentialAuditEntry) error
+
+	// LastStoredCredentialAudit retorna o último registo da cadeia do tenant, ou nil quando está vazia
+	LastStoredCredentialAudit(ctx context.Context, tenantID string) (*StoredCredentialAuditEntry, error)
+
+	// ListStoredCredentialAudit lista por sequência os registos da credencial, ou de toda a cadeia do
+	// tenant quando credentialID é vazio
+	ListStoredCredentialAudit(ctx context.Context, tenantID, credentialID string) ([]*StoredCredentialAuditEntry, error)
+}
+
+// InMemoryStoredCredentialStore armazena as credenciais e a cadeia de auditoria em memória
+type InMemoryStoredCredentialStore struct {
+	credentials map[string]*StoredCredential
+	audit       map[string][]*StoredCredentialAuditEntry // Por tenant
+	mutex       sync.RWMutex
+}
+
+// NewInMemoryStoredCredentialStore cria um novo armazenamento em memória
+func NewInMemoryStoredCredentialStore() *InMemoryStoredCredentialStore {
+	return &InMemoryStoredCredentialStore{
+		credentials: make(map[string]*StoredCredential),
+		audit:       make(map[string][]*StoredCredentialAuditEntry),
+	}
+}
+
+// CreateStoredCredential grava uma cópia da credencial
+func (s *InMemoryStoredCredentialStore) CreateStoredCredential(ctx context.Context, credential *StoredCredential) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.credentials[credential.ID] = copyStoredCredential(credential)
+	return nil
+}
+
+// UpdateStoredCredential grava uma cópia da credencial se o estado atual for fromStatus
+func (s *InMemoryStoredCredentialStore) UpdateStoredCredential(ctx context.Context, credential *StoredCredential, fromStatus string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, ok := s.credentials[credential.ID]
+	if !ok {
+		return ErrStoredCredentialNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrStoredCredentialStatusChanged
+	}
+	s.credentials[credential.ID] = copyStoredCredential(credential)
+	return nil
+}
+
+// GetStoredCredential retorna uma cópia da credencial
+func (s *InMemoryStoredCredentialStore) GetStoredCredential(ctx context.Context, credentialID string) (*StoredCredential, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	credential, ok := s.credentials[credentialID]
+	if !ok {
+		return nil, ErrStoredCredentialNotFound
+	}
+	return copyStoredCredential(credential), nil
+}
+
+// ListStoredCredentials retorna cópias das credenciais do filtro
+func (s *InMemoryStoredCredentialStore) ListStoredCredentials(ctx context.Context, filter StoredCredentialFilter) ([]*StoredCredential, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	credentials := make([]*StoredCredential, 0)
+	for _, credential := range s.credentials {
+		if filter.TenantID != "" && credential.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.UserID != "" && credential.UserID != filter.UserID {
+			continue
+		}
+		if filter.MerchantID != "" && credential.MerchantID != filter.MerchantID {
+			continue
+		}
+		if filter.Status != "" && credential.Status != filter.Status {
+			continue
+		}
+		credentials = append(credentials, copyStoredCredential(credential))
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		if !credentials[i].CreatedAt.Equal(credentials[j].CreatedAt) {
+			return credentials[i].CreatedAt.After(credentials[j].CreatedAt)
+		}
+		return credentials[i].ID < credentials[j].ID
+	})
+	return credentials, nil
+}
+
+// AppendStoredCredentialAudit acrescenta uma cópia do registo à cadeia do tenant
+func (s *InMemoryStoredCredentialStore) AppendStoredCredentialAudit(ctx context.Context, entry *StoredCredentialAuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chain := s.audit[entry.TenantID]
+	if entry.Sequence != int64(len(chain)+1) {
+		return ErrStoredCredentialAuditConflict
+	}
+	copied := *entry
+	s.audit[entry.TenantID] = append(chain, &copied)
+	return nil
+}
+
+// LastStoredCredentialAudit retorna uma cópia do último registo da cadeia do tenant
+func (s *InMemoryStoredCredentialStore) LastStoredCredentialAudit(ctx context.Context, tenantID string) (*StoredCredentialAuditEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	chain := s.audit[tenantID]
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	copied := *chain[len(chain)-1]
+	return &copied, nil
+}
+
+// ListStoredCredentialAudit retorna cópias dos registos por sequência
+func (s *InMemoryStoredCredentialStore) ListStoredCredentialAudit(ctx context.Context, tenantID, credentialID string) ([]*StoredCredentialAuditEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := make([]*StoredCredentialAuditEntry, 0)
+	for _, entry := range s.audit[tenantID] {
+		if credentialID != "" && entry.CredentialID != credentialID {
+			continue
+		}
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+// copyStoredCredential copia a credencial, o PAN cifrado e as utilizações consentidas
+func copyStoredCredential(credential *StoredCredential) *StoredCredential {
+	copied := *credential
+	copied.EncryptedPAN = append([]byte(nil), credential.EncryptedPAN...)
+	copied.Consent.Reasons = append([]string(nil), credential.Consent.Reasons...)
+	return &copied
+}
@@ -0,0 +1,257 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+)
+
+// ErrSupportUnauthenticated indica um pedido da API de suporte sem credenciais válidas
+var ErrSupportUnauthenticated = errors.New("operador de suporte não autenticado")
+
+// SupportPrincipal é o operador autenticado de um pedido da API de suporte
+type SupportPrincipal struct {
+	Operator string   `json:"operator"`
+	Roles    []string `json:"roles"`
+}
+
+// HasRole indica se o operador tem a função indicada
+func (p *SupportPrincipal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportAuthenticator autentica o operador de um pedido da API de suporte; pedidos sem
+// credenciais válidas retornam ErrSupportUnauthenticated
+type SupportAuthenticator interface {
+	Authenticate(r *http.Request) (*SupportPrincipal, error)
+}
+
+// ClientCertificateAuthenticator identifica o operador pelo certificado de cliente verificado
+// no handshake mTLS: o CN é o operador e as OU são as funções
+type ClientCertificateAuthenticator struct{}
+
+// Authenticate retorna o operador do certificado de cliente verificado
+func (ClientCertificateAuthenticator) Authenticate(r *http.Request) (*SupportPrincipal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrSupportUnauthenticated
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	if leaf.Subject.CommonName == "" {
+		return nil, fmt.Errorf("%w: certificado sem CN", ErrSupportUnauthenticated)
+	}
+	return &SupportPrincipal{
+		Operator: leaf.Subject.CommonName,
+		Roles:    append([]string(nil), leaf.Subject.OrganizationalUnit...),
+	}, nil
+}
+
+// IAMTokenIntrospector valida o token Bearer do operador no endpoint de introspeção do IAM
+// (RFC 7662); o operador é o sub do token e as funções vêm da claim roles
+type IAMTokenIntrospector struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	Audience     string // Audiência exigida no token; vazio não verifica
+	Client       *http.Client
+}
+
+// tokenIntrospection é a resposta do endpoint de introspeção
+type tokenIntrospection struct {
+	Active   bool            `json:"active"`
+	Subject  string          `json:"sub"`
+	Username string          `json:"username"`
+	Expiry   int64           `json:"exp"`
+	Audience json.RawMessage `json:"aud"`
+	Roles    []string        `json:"roles"`
+}
+
+// hasAudience indica se a claim aud, texto ou lista, inclui a audiência
+func (t *tokenIntrospection) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(t.Audience, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(t.Audience, &list); err == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticate valida o token Bearer do pedido no IAM
+func (i *IAMTokenIntrospector) Authenticate(r *http.Request) (*SupportPrincipal, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrSupportUnauthenticated
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, i.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar pedido de introspeção: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if i.ClientID != "" {
+		req.SetBasicAuth(i.ClientID, i.ClientSecret)
+	}
+
+	client := i.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("falha na introspeção do token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspeção do token retornou %d", resp.StatusCode)
+	}
+
+	var introspection tokenIntrospection
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&introspection); err != nil {
+		return nil, fmt.Errorf("resposta de introspeção inválida: %w", err)
+	}
+	if !introspection.Active {
+		return nil, fmt.Errorf("%w: token inativo", ErrSupportUnauthenticated)
+	}
+	if introspection.Expiry != 0 && time.Now().Unix() >= introspection.Expiry {
+		return nil, fmt.Errorf("%w: token expirado", ErrSupportUnauthenticated)
+	}
+	if i.Audience != "" && !introspection.hasAudience(i.Audience) {
+		return nil, fmt.Errorf("%w: audiência do token inválida", ErrSupportUnauthenticated)
+	}
+
+	operator := introspection.Subject
+	if operator == "" {
+		operator = introspection.Username
+	}
+	if operator == "" {
+		return nil, fmt.Errorf("%w: token sem sujeito", ErrSupportUnauthenticated)
+	}
+	return &SupportPrincipal{Operator: operator, Roles: introspection.Roles}, nil
+}
+
+// supportPrincipalKey é a chave do operador autenticado no contexto do pedido
+type supportPrincipalKey struct{}
+
+// WithSupportPrincipal associa o operador autenticado ao contexto
+func WithSupportPrincipal(ctx context.Context, principal *SupportPrincipal) context.Context {
+	return context.WithValue(ctx, supportPrincipalKey{}, principal)
+}
+
+// SupportPrincipalFrom retorna o operador autenticado do contexto
+func SupportPrincipalFrom(ctx context.Context) (*SupportPrincipal, bool) {
+	principal, ok := ctx.Value(supportPrincipalKey{}).(*SupportPrincipal)
+	return principal, ok && principal != nil
+}
+
+// supportOperator retorna o operador autenticado do pedido, usado nos registos de auditoria
+func supportOperator(r *http.Request) string {
+	if principal, ok := SupportPrincipalFrom(r.Context()); ok {
+		return principal.Operator
+	}
+	return ""
+}
+
+// SupportAuth exige um operador autenticado nos pedidos da API de suporte
+type SupportAuth struct {
+	authenticator SupportAuthenticator
+	logger        logging.Logger
+}
+
+// NewSupportAuth cria a autenticação da API de suporte; sem autenticador a API não pode ser exposta
+func NewSupportAuth(authenticator SupportAuthenticator) (*SupportAuth, error) {
+	if authenticator == nil {
+		return nil, errors.New("API de suporte exige a introspeção de tokens do IAM ou mTLS com certificados de cliente")
+	}
+
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-support-auth",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	return &SupportAuth{
+		authenticator: authenticator,
+		logger:        obsAdapter.Logger(),
+	}, nil
+}
+
+// Middleware rejeita com 401 os pedidos sem operador autenticado e associa o operador ao contexto;
+// deve envolver o subrouter onde os handlers de suporte registam as suas rotas
+func (a *SupportAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.authenticator.Authenticate(r)
+		if err != nil || principal == nil || principal.Operator == "" {
+			reason := "sem operador"
+			if err != nil {
+				reason = err.Error()
+			}
+			a.logger.Warn("Pedido da API de suporte não autenticado",
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"error", reason)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="support"`)
+			respondWithError(w, http.StatusUnauthorized, "unauthenticated", "Operador não autenticado")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithSupportPrincipal(r.Context(), principal)))
+	})
+}
+
+// LoadSupportTLSConfig carrega o certificado da API de suporte; com a CA dos operadores exige
+// certificados de cliente verificados. Sem certificado retorna nil (API sem TLS)
+func LoadSupportTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("a CA dos operadores exige o certificado e a chave da API de suporte")
+		}
+		return nil, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao carregar certificado: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pemData, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler CA dos operadores: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, errors.New("CA dos operadores sem certificados PEM")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
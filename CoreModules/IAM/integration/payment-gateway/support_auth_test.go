@@ -0,0 +1,159 @@
+package paymentgateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIAMIntrospection simula o endpoint de introspeção do IAM com os tokens indicados
+func newTestIAMIntrospection(t *testing.T, tokens map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "payment-gateway" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		claims, found := tokens[r.PostForm.Get("token")]
+		if !found {
+			claims = map[string]interface{}{"active": false}
+		}
+		json.NewEncoder(w).Encode(claims)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// asSupportOperator associa um operador autenticado ao pedido, como faz SupportAuth.Middleware
+func asSupportOperator(req *http.Request, operator string, roles ...string) *http.Request {
+	return req.WithContext(WithSupportPrincipal(req.Context(), &SupportPrincipal{Operator: operator, Roles: roles}))
+}
+
+func TestIAMTokenIntrospectorAuthenticate(t *testing.T) {
+	expired := time.Now().Add(-time.Minute).Unix()
+	valid := time.Now().Add(time.Hour).Unix()
+	server := newTestIAMIntrospection(t, map[string]map[string]interface{}{
+		"valid":         {"active": true, "sub": "ana", "exp": valid, "aud": "payment-gateway-support", "roles": []string{"payments_supervisor"}},
+		"audience-list": {"active": true, "sub": "rui", "aud": []string{"other", "payment-gateway-support"}},
+		"username-only": {"active": true, "username": "eva", "aud": "payment-gateway-support"},
+		"expired":       {"active": true, "sub": "ana", "exp": expired, "aud": "payment-gateway-support"},
+		"wrong-aud":     {"active": true, "sub": "ana", "aud": "other"},
+		"no-subject":    {"active": true, "aud": "payment-gateway-support"},
+	})
+	introspector := &IAMTokenIntrospector{
+		URL:          server.URL,
+		ClientID:     "payment-gateway",
+		ClientSecret: "secret",
+		Audience:     "payment-gateway-support",
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		operator      string
+		roles         []string
+	}{
+		{name: "token válido", authorization: "Bearer valid", operator: "ana", roles: []string{"payments_supervisor"}},
+		{name: "audiência em lista", authorization: "Bearer audience-list", operator: "rui"},
+		{name: "username sem sub", authorization: "bearer username-only", operator: "eva"},
+		{name: "sem cabeçalho"},
+		{name: "esquema diferente", authorization: "Basic valid"},
+		{name: "token desconhecido", authorization: "Bearer unknown"},
+		{name: "token expirado", authorization: "Bearer expired"},
+		{name: "audiência inválida", authorization: "Bearer wrong-aud"},
+		{name: "token sem sujeito", authorization: "Bearer no-subject"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/support/transactions/tx-1/risk-explanation", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			principal, err := introspector.Authenticate(req)
+			if tt.operator == "" {
+				assert.ErrorIs(t, err, ErrSupportUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.operator, principal.Operator)
+			assert.Equal(t, tt.roles, principal.Roles)
+		})
+	}
+}
+
+func TestIAMTokenIntrospectorRejectedClient(t *testing.T) {
+	server := newTestIAMIntrospection(t, nil)
+	introspector := &IAMTokenIntrospector{URL: server.URL, ClientID: "payment-gateway", ClientSecret: "wrong"}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+
+	_, err := introspector.Authenticate(req)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSupportUnauthenticated)
+}
+
+func TestClientCertificateAuthenticator(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, err := ClientCertificateAuthenticator{}.Authenticate(req)
+	assert.ErrorIs(t, err, ErrSupportUnauthenticated)
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+		Subject: pkix.Name{CommonName: "ana", OrganizationalUnit: []string{"payments_supervisor", "support"}},
+	}}}}
+	principal, err := ClientCertificateAuthenticator{}.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "ana", principal.Operator)
+	assert.True(t, principal.HasRole("support"))
+	assert.False(t, principal.HasRole("treasury_manager"))
+
+	req.TLS.VerifiedChains[0][0].Subject.CommonName = ""
+	_, err = ClientCertificateAuthenticator{}.Authenticate(req)
+	assert.ErrorIs(t, err, ErrSupportUnauthenticated)
+}
+
+func TestSupportAuthMiddleware(t *testing.T) {
+	_, err := NewSupportAuth(nil)
+	assert.Error(t, err)
+
+	server := newTestIAMIntrospection(t, map[string]map[string]interface{}{
+		"valid": {"active": true, "sub": "ana"},
+	})
+	auth, err := NewSupportAuth(&IAMTokenIntrospector{URL: server.URL, ClientID: "payment-gateway", ClientSecret: "secret"})
+	require.NoError(t, err)
+
+	var operator string
+	router := mux.NewRouter()
+	router.Use(auth.Middleware)
+	router.HandleFunc("/support/ping", func(w http.ResponseWriter, r *http.Request) {
+		operator = supportOperator(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/support/ping", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer realm="support"`, rec.Header().Get("WWW-Authenticate"))
+	assert.Empty(t, operator)
+
+	req := httptest.NewRequest(http.MethodGet, "/support/ping", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "ana", operator)
+}
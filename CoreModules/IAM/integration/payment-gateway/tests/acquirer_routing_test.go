@@ -0,0 +1,446 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+// acquirerResponse é a resposta simulada de um adquirente
+type acquirerResponse struct {
+	result *paymentgateway.AcquirerAuthorizationResult
+	err    error
+}
+
+// fakeAcquirerClient responde segundo as respostas configuradas; os restantes adquirentes aprovam
+type fakeAcquirerClient struct {
+	mutex     sync.Mutex
+	responses map[string]acquirerResponse
+	calls     []string
+}
+
+func (c *fakeAcquirerClient) Authorize(ctx context.Context, acquirer paymentgateway.AcquirerConfig, req *paymentgateway.AcquirerAuthorizationRequest) (*paymentgateway.AcquirerAuthorizationResult, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls = append(c.calls, acquirer.AcquirerID)
+	if response, ok := c.responses[acquirer.AcquirerID]; ok {
+		return response.result, response.err
+	}
+	return &paymentgateway.AcquirerAuthorizationResult{
+		Approved:          true,
+		ResponseCode:      "00",
+		ApprovalCode:      "APR-" + acquirer.AcquirerID,
+		AcquirerReference: fmt.Sprintf("%s-%d", acquirer.AcquirerID, req.Attempt),
+	}, nil
+}
+
+func declined(code string) acquirerResponse {
+	return acquirerResponse{result: &paymentgateway.AcquirerAuthorizationResult{ResponseCode: code}}
+}
+
+func unavailable(acquirerID string) acquirerResponse {
+	return acquirerResponse{err: fmt.Errorf("%w: %s retornou status 503", paymentgateway.ErrAcquirerUnavailable, acquirerID)}
+}
+
+// testAcquirerRoutingConfig configura três adquirentes para Angola e um quarto só para o Brasil
+// Para 1000: acq-a custa 20, acq-b custa 15 e acq-c não tem tarifa; acq-d custa 5
+func testAcquirerRoutingConfig() paymentgateway.AcquirerRoutingConfig {
+	currencies := []string{"AOA", "USD", "BRL"}
+	return paymentgateway.AcquirerRoutingConfig{
+		Acquirers: []paymentgateway.AcquirerConfig{
+			{AcquirerID: "acq-a", Currencies: currencies, Priority: 2, Rates: map[string]paymentgateway.CostRate{"*": {PercentFee: 2}}},
+			{AcquirerID: "acq-b", Currencies: currencies, Priority: 1, Rates: map[string]paymentgateway.CostRate{"*": {FixedFee: 5, PercentFee: 1}}, SoftDeclineCodes: []string{"62"}},
+			{AcquirerID: "acq-c", Currencies: currencies, Priority: 0},
+			{AcquirerID: "acq-d", RegionCodes: []string{paymentgateway.RegionBrazil}, Currencies: currencies, Priority: 3, Rates: map[string]paymentgateway.CostRate{"*": {PercentFee: 0.5}}},
+			{AcquirerID: "acq-e", Currencies: currencies, Disabled: true},
+		},
+		Rules: []paymentgateway.RoutingRule{
+			{RuleID: "default", RegionCode: "*", PaymentMethod: "*", Strategy: paymentgateway.RoutingStrategyLeastCost, MaxAttempts: 2},
+			{RuleID: "ao-any", RegionCode: paymentgateway.RegionAngola, PaymentMethod: "*", Strategy: paymentgateway.RoutingStrategyHighestApproval, Acquirers: []string{"acq-a", "acq-b"}},
+			{RuleID: "ao-card", RegionCode: paymentgateway.RegionAngola, PaymentMethod: paymentgateway.PaymentMethodCard, Strategy: paymentgateway.RoutingStrategyPriority},
+		},
+		Experiments: []paymentgateway.RoutingExperiment{{
+			ExperimentID:  "exp-ao-wallet",
+			RegionCode:    paymentgateway.RegionAngola,
+			PaymentMethod: paymentgateway.PaymentMethodDigitalWallet,
+			Variants: []paymentgateway.RoutingExperimentVariant{
+				{Name: "controlo", Strategy: paymentgateway.RoutingStrategyHighestApproval, Weight: 1},
+				{Name: "prioridade", Strategy: paymentgateway.RoutingStrategyPriority, Weight: 1},
+			},
+		}},
+	}
+}
+
+func newAcquirerRoutingService(t *testing.T, responses map[string]acquirerResponse) (*paymentgateway.AcquirerRoutingService, *paymentgateway.InMemoryAcquirerRoutingStore, *fakeAcquirerClient) {
+	t.Helper()
+
+	store := paymentgateway.NewInMemoryAcquirerRoutingStore()
+	client := &fakeAcquirerClient{responses: responses}
+	service, err := paymentgateway.NewAcquirerRoutingService(testAcquirerRoutingConfig(), store, client)
+	require.NoError(t, err)
+	clock := newTestClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+	return service, store, client
+}
+
+func routingRequest(transactionID, region, method, currency string) *paymentgateway.PaymentRequest {
+	return &paymentgateway.PaymentRequest{
+		TransactionID: transactionID,
+		TenantID:      "tenant-1",
+		MerchantID:    "merchant-1",
+		RegionCode:    region,
+		PaymentMethod: method,
+		Amount:        1000,
+		Currency:      currency,
+	}
+}
+
+func candidateIDs(decision *paymentgateway.RoutingDecision) []string {
+	ids := make([]string, 0, len(decision.Candidates))
+	for _, candidate := range decision.Candidates {
+		ids = append(ids, candidate.AcquirerID)
+	}
+	return ids
+}
+
+func TestAcquirerRoutingStrategies(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *paymentgateway.PaymentRequest
+		ruleID     string
+		strategy   string
+		candidates []string
+		calls      []string
+	}{
+		{
+			name:       "prioridade na regra do mercado e método",
+			req:        routingRequest("tx-1", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "AOA"),
+			ruleID:     "ao-card",
+			strategy:   paymentgateway.RoutingStrategyPriority,
+			candidates: []string{"acq-c", "acq-b", "acq-a"},
+			calls:      []string{"acq-c"},
+		},
+		{
+			name:       "maior aprovação limitada aos adquirentes da regra do mercado",
+			req:        routingRequest("tx-2", paymentgateway.RegionAngola, paymentgateway.PaymentMethodBankTransfer, "AOA"),
+			ruleID:     "ao-any",
+			strategy:   paymentgateway.RoutingStrategyHighestApproval,
+			candidates: []string{"acq-a", "acq-b"},
+			calls:      []string{"acq-a"},
+		},
+		{
+			name:       "menor custo na regra genérica, adquirentes sem tarifa no fim",
+			req:        routingRequest("tx-3", paymentgateway.RegionBrazil, paymentgateway.PaymentMethodPIX, "BRL"),
+			ruleID:     "default",
+			strategy:   paymentgateway.RoutingStrategyLeastCost,
+			candidates: []string{"acq-d", "acq-b", "acq-a", "acq-c"},
+			calls:      []string{"acq-d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, store, client := newAcquirerRoutingService(t, nil)
+
+			// acq-a aprova 95% e acq-b 50%: (95+17)/120 = 0,9333 e (50+17)/120 = 0,5583
+			require.NoError(t, store.RecordAcquirerStats(ctx, &paymentgateway.AcquirerDailyStats{
+				StatsDate: "2026-10-16", AcquirerID: "acq-a", RegionCode: paymentgateway.RegionAngola,
+				PaymentMethod: paymentgateway.PaymentMethodCard, Attempts: 100, Approvals: 95,
+			}))
+			require.NoError(t, store.RecordAcquirerStats(ctx, &paymentgateway.AcquirerDailyStats{
+				StatsDate: "2026-10-15", AcquirerID: "acq-b", RegionCode: paymentgateway.RegionAngola,
+				PaymentMethod: paymentgateway.PaymentMethodCard, Attempts: 100, Approvals: 50,
+			}))
+
+			decision, result, err := service.Route(ctx, tt.req, nil)
+			require.NoError(t, err)
+			require.NotNil(t, decision)
+			assert.Equal(t, tt.ruleID, decision.RuleID)
+			assert.Equal(t, tt.strategy, decision.Strategy)
+			assert.Equal(t, tt.candidates, candidateIDs(decision))
+			assert.Equal(t, tt.calls, client.calls)
+			assert.Equal(t, paymentgateway.AcquirerOutcomeApproved, decision.Outcome)
+			assert.Equal(t, tt.calls[0], decision.AcquirerID)
+			assert.Equal(t, "APR-"+tt.calls[0], result.ApprovalCode)
+		})
+	}
+
+	t.Run("taxas de aprovação e custos dos candidatos", func(t *testing.T) {
+		ctx := context.Background()
+		service, store, _ := newAcquirerRoutingService(t, nil)
+		require.NoError(t, store.RecordAcquirerStats(ctx, &paymentgateway.AcquirerDailyStats{
+			StatsDate: "2026-10-16", AcquirerID: "acq-a", Attempts: 100, Approvals: 95,
+		}))
+
+		decision, _, err := service.Route(ctx, routingRequest("tx-4", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "AOA"), nil)
+		require.NoError(t, err)
+		rates := make(map[string]paymentgateway.RoutingCandidate)
+		for _, candidate := range decision.Candidates {
+			rates[candidate.AcquirerID] = candidate
+		}
+		assert.Equal(t, 0.9333, rates["acq-a"].ApprovalRate)
+		assert.Equal(t, 0.85, rates["acq-c"].ApprovalRate)
+		assert.Equal(t, 20.0, rates["acq-a"].EstimatedCost)
+		assert.Equal(t, 15.0, rates["acq-b"].EstimatedCost)
+	})
+}
+
+func TestAcquirerRoutingFailover(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string]acquirerResponse
+		calls     []string
+		outcomes  []string
+		outcome   string
+		acquirer  string
+		approved  bool
+		err       error
+	}{
+		{
+			name:      "indisponibilidade e recusa transitória passam ao seguinte",
+			responses: map[string]acquirerResponse{"acq-c": unavailable("acq-c"), "acq-b": declined("05")},
+			calls:     []string{"acq-c", "acq-b", "acq-a"},
+			outcomes:  []string{paymentgateway.AcquirerOutcomeUnavailable, paymentgateway.AcquirerOutcomeSoftDecline, paymentgateway.AcquirerOutcomeApproved},
+			outcome:   paymentgateway.AcquirerOutcomeApproved,
+			acquirer:  "acq-a",
+			approved:  true,
+		},
+		{
+			name:      "recusa definitiva termina a cascata",
+			responses: map[string]acquirerResponse{"acq-c": declined("51")},
+			calls:     []string{"acq-c"},
+			outcomes:  []string{paymentgateway.AcquirerOutcomeHardDecline},
+			outcome:   paymentgateway.AcquirerOutcomeHardDecline,
+			acquirer:  "acq-c",
+		},
+		{
+			name:      "código transitório de outro adquirente é definitivo",
+			responses: map[string]acquirerResponse{"acq-c": declined("62")},
+			calls:     []string{"acq-c"},
+			outcomes:  []string{paymentgateway.AcquirerOutcomeHardDecline},
+			outcome:   paymentgateway.AcquirerOutcomeHardDecline,
+			acquirer:  "acq-c",
+		},
+		{
+			name: "recusas transitórias em todos os adquirentes",
+			responses: map[string]acquirerResponse{
+				"acq-c": {result: &paymentgateway.AcquirerAuthorizationResult{ResponseCode: "57", SoftDecline: true}},
+				"acq-b": declined("62"),
+				"acq-a": declined("91"),
+			},
+			calls:    []string{"acq-c", "acq-b", "acq-a"},
+			outcomes: []string{paymentgateway.AcquirerOutcomeSoftDecline, paymentgateway.AcquirerOutcomeSoftDecline, paymentgateway.AcquirerOutcomeSoftDecline},
+			outcome:  paymentgateway.AcquirerOutcomeSoftDecline,
+			acquirer: "acq-a",
+		},
+		{
+			name: "nenhum adquirente responde",
+			responses: map[string]acquirerResponse{
+				"acq-c": unavailable("acq-c"),
+				"acq-b": {err: fmt.Errorf("%w: acq-b retornou status 400", paymentgateway.ErrAcquirerRequestRejected)},
+				"acq-a": unavailable("acq-a"),
+			},
+			calls:    []string{"acq-c", "acq-b", "acq-a"},
+			outcomes: []string{paymentgateway.AcquirerOutcomeUnavailable, paymentgateway.AcquirerOutcomeUnavailable, paymentgateway.AcquirerOutcomeUnavailable},
+			outcome:  paymentgateway.AcquirerOutcomeUnavailable,
+			err:      paymentgateway.ErrNoAcquirerAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _, client := newAcquirerRoutingService(t, tt.responses)
+
+			decision, result, err := service.Route(ctx, routingRequest("tx-1", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "AOA"), nil)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.approved, result.Approved)
+			}
+			require.NotNil(t, decision)
+			assert.Equal(t, tt.calls, client.calls)
+			assert.Equal(t, tt.outcome, decision.Outcome)
+			assert.Equal(t, tt.acquirer, decision.AcquirerID)
+
+			outcomes := make([]string, 0, len(decision.Attempts))
+			for _, attempt := range decision.Attempts {
+				outcomes = append(outcomes, attempt.Outcome)
+			}
+			assert.Equal(t, tt.outcomes, outcomes)
+
+			saved, err := service.GetRoutingDecision(ctx, "tenant-1", "tx-1")
+			require.NoError(t, err)
+			assert.Equal(t, decision.Outcome, saved.Outcome)
+			assert.Len(t, saved.Attempts, len(tt.calls))
+		})
+	}
+
+	t.Run("limite de tentativas da regra", func(t *testing.T) {
+		service, _, client := newAcquirerRoutingService(t, map[string]acquirerResponse{
+			"acq-d": unavailable("acq-d"),
+			"acq-b": unavailable("acq-b"),
+		})
+		decision, _, err := service.Route(context.Background(), routingRequest("tx-2", paymentgateway.RegionBrazil, paymentgateway.PaymentMethodPIX, "BRL"), nil)
+		assert.ErrorIs(t, err, paymentgateway.ErrNoAcquirerAvailable)
+		assert.Equal(t, []string{"acq-d", "acq-b"}, client.calls)
+		assert.Len(t, decision.Candidates, 4)
+	})
+
+	t.Run("sem adquirente elegível a autorização do gateway mantém-se", func(t *testing.T) {
+		service, _, client := newAcquirerRoutingService(t, nil)
+		decision, result, err := service.Route(context.Background(), routingRequest("tx-3", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "EUR"), nil)
+		require.NoError(t, err)
+		assert.Nil(t, decision)
+		assert.Nil(t, result)
+		assert.Empty(t, client.calls)
+
+		_, err = service.GetRoutingDecision(context.Background(), "tenant-1", "tx-3")
+		assert.ErrorIs(t, err, paymentgateway.ErrRoutingDecisionNotFound)
+	})
+}
+
+func TestAcquirerRoutingPerformanceReport(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newAcquirerRoutingService(t, map[string]acquirerResponse{
+		"acq-c": unavailable("acq-c"),
+		"acq-b": declined("05"),
+	})
+	_, _, err := service.Route(ctx, routingRequest("tx-1", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "AOA"), nil)
+	require.NoError(t, err)
+
+	_, err = service.GetRoutingDecision(ctx, "tenant-2", "tx-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrRoutingDecisionNotFound)
+
+	report, err := service.PerformanceReport(ctx, paymentgateway.AcquirerPerformanceFilter{From: "2026-10-16", To: "2026-10-16"})
+	require.NoError(t, err)
+	require.Len(t, report.Performance, 3)
+
+	a, b, c := report.Performance[0], report.Performance[1], report.Performance[2]
+	assert.Equal(t, "acq-a", a.AcquirerID)
+	assert.Equal(t, int64(1), a.Approvals)
+	assert.Equal(t, 1.0, a.ApprovalRate)
+	assert.Equal(t, 1000.0, a.ApprovedVolume)
+	assert.Equal(t, 20.0, a.EstimatedCost)
+	assert.Equal(t, 200.0, a.CostRateBps)
+	assert.Equal(t, int64(1), b.SoftDeclines)
+	assert.Equal(t, 1.0, b.SoftDeclineRate)
+	assert.Equal(t, int64(1), c.Errors)
+	assert.Equal(t, 1.0, c.ErrorRate)
+
+	report, err = service.PerformanceReport(ctx, paymentgateway.AcquirerPerformanceFilter{From: "2026-10-16", To: "2026-10-16", AcquirerID: "acq-b"})
+	require.NoError(t, err)
+	require.Len(t, report.Performance, 1)
+
+	for _, filter := range []paymentgateway.AcquirerPerformanceFilter{
+		{From: "2026-10-17", To: "2026-10-16"},
+		{From: "2026-01-01", To: "2026-10-16"},
+		{From: "16/10/2026", To: "2026-10-16"},
+	} {
+		_, err := service.PerformanceReport(ctx, filter)
+		assert.ErrorIs(t, err, paymentgateway.ErrAcquirerPerformanceFilterInvalid)
+	}
+}
+
+func TestAcquirerRoutingExperiment(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newAcquirerRoutingService(t, nil)
+
+	strategies := map[string]string{
+		"controlo":   paymentgateway.RoutingStrategyHighestApproval,
+		"prioridade": paymentgateway.RoutingStrategyPriority,
+	}
+	variants := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		transactionID := fmt.Sprintf("tx-%d", i)
+		decision, _, err := service.Route(ctx, routingRequest(transactionID, paymentgateway.RegionAngola, paymentgateway.PaymentMethodDigitalWallet, "AOA"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "exp-ao-wallet", decision.ExperimentID)
+		assert.Equal(t, "ao-any", decision.RuleID)
+		assert.Equal(t, strategies[decision.Variant], decision.Strategy)
+		if decision.Variant == "prioridade" {
+			assert.Equal(t, []string{"acq-b", "acq-a"}, candidateIDs(decision))
+		}
+		variants[transactionID] = decision.Variant
+	}
+
+	// As novas tentativas do mesmo pagamento usam sempre a mesma variante
+	for transactionID, variant := range variants {
+		decision, _, err := service.Route(ctx, routingRequest(transactionID, paymentgateway.RegionAngola, paymentgateway.PaymentMethodDigitalWallet, "AOA"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, variant, decision.Variant)
+	}
+
+	report, err := service.ExperimentReport(ctx, "exp-ao-wallet")
+	require.NoError(t, err)
+	require.Len(t, report.Variants, 2)
+	var transactions int64
+	for _, result := range report.Variants {
+		assert.Positive(t, result.Transactions, result.Variant)
+		assert.Equal(t, 1.0, result.ApprovalRate)
+		assert.Equal(t, 1.0, result.AttemptsPerPayment)
+		transactions += result.Transactions
+	}
+	assert.Equal(t, int64(80), transactions)
+
+	_, err = service.ExperimentReport(ctx, "exp-unknown")
+	assert.ErrorIs(t, err, paymentgateway.ErrRoutingExperimentNotFound)
+
+	// Fora do mercado e método da experiência aplica-se a estratégia da regra
+	decision, _, err := service.Route(ctx, routingRequest("tx-card", paymentgateway.RegionAngola, paymentgateway.PaymentMethodCard, "AOA"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, decision.ExperimentID)
+	assert.Equal(t, paymentgateway.RoutingStrategyPriority, decision.Strategy)
+}
+
+func TestAcquirerRoutingConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(config *paymentgateway.AcquirerRoutingConfig)
+	}{
+		{"adquirente duplicado", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Acquirers = append(config.Acquirers, paymentgateway.AcquirerConfig{AcquirerID: "acq-a"})
+		}},
+		{"tarifa inválida", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Acquirers[0].Rates = map[string]paymentgateway.CostRate{"*": {PercentFee: -1}}
+		}},
+		{"estratégia padrão desconhecida", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.DefaultStrategy = "round_robin"
+		}},
+		{"regra com adquirente desconhecido", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Rules[1].Acquirers = []string{"acq-x"}
+		}},
+		{"regra duplicada", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Rules = append(config.Rules, paymentgateway.RoutingRule{RuleID: "dup", RegionCode: "*", PaymentMethod: "*", Strategy: paymentgateway.RoutingStrategyPriority})
+		}},
+		{"experiência com uma só variante", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Experiments[0].Variants = config.Experiments[0].Variants[:1]
+		}},
+		{"variante sem peso", func(config *paymentgateway.AcquirerRoutingConfig) {
+			config.Experiments[0].Variants[1].Weight = 0
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testAcquirerRoutingConfig()
+			tt.mutate(&config)
+			_, err := paymentgateway.NewAcquirerRoutingService(config, paymentgateway.NewInMemoryAcquirerRoutingStore(), &fakeAcquirerClient{})
+			assert.ErrorIs(t, err, paymentgateway.ErrAcquirerRoutingConfigInvalid)
+		})
+	}
+
+	// Sem cliente, cada adquirente precisa da URL da API
+	_, err := paymentgateway.NewAcquirerRoutingService(testAcquirerRoutingConfig(), paymentgateway.NewInMemoryAcquirerRoutingStore(), nil)
+	assert.ErrorIs(t, err, paymentgateway.ErrAcquirerRoutingConfigInvalid)
+}
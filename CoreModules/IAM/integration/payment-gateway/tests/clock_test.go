@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"sync"
+	"time"
+)
+
+// testClock é um relógio controlado pelos testes, seguro para os agendadores em segundo plano
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{now: now}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+// testRateCards devolve as tabelas de custos da menos para a mais específica
+func testRateCards() []paymentgateway.CostRateCard {
+	return []paymentgateway.CostRateCard{
+		{
+			RateCardID: "global",
+			RegionCode: "*",
+			Currency:   "*",
+			PSPRates:   map[string]paymentgateway.CostRate{"*": {PercentFee: 3}},
+		},
+		{
+			RateCardID: "ao-any",
+			RegionCode: paymentgateway.RegionAngola,
+			Currency:   "*",
+			PSPRates:   map[string]paymentgateway.CostRate{"*": {PercentFee: 2}},
+		},
+		{
+			RateCardID: "ao-aoa",
+			RegionCode: paymentgateway.RegionAngola,
+			Currency:   "AOA",
+			PSPRates: map[string]paymentgateway.CostRate{
+				paymentgateway.PaymentMethodCard: {FixedFee: 50, PercentFee: 1.5, MinFee: 100, MaxFee: 5000},
+				"*":                              {PercentFee: 1},
+			},
+			ScreeningRates: map[string]paymentgateway.CostRate{
+				paymentgateway.VerificationLevelStandard: {FixedFee: 20},
+				"*":                                      {FixedFee: 10},
+			},
+			ExtraCheckFee: 5,
+			BureauRate:    paymentgateway.CostRate{FixedFee: 30},
+		},
+	}
+}
+
+func costRequest(transactionID, merchantID, region, currency, method string, amount float64) *paymentgateway.PaymentRequest {
+	return &paymentgateway.PaymentRequest{
+		TransactionID: transactionID,
+		TenantID:      "tenant-1",
+		RegionCode:    region,
+		MerchantID:    merchantID,
+		PaymentMethod: method,
+		Amount:        amount,
+		Currency:      currency,
+	}
+}
+
+func TestCostAttributionRateCards(t *testing.T) {
+	service, err := paymentgateway.NewCostAccountingService(paymentgateway.CostAccountingConfig{
+		RateCards: testRateCards(),
+	}, paymentgateway.NewInMemoryCostStore())
+	require.NoError(t, err)
+
+	var order []string
+	for _, card := range service.RateCards() {
+		order = append(order, card.RateCardID)
+	}
+	assert.Equal(t, []string{"ao-aoa", "ao-any", "global"}, order)
+
+	approved := paymentgateway.TransactionStatusApproved
+	standard := paymentgateway.CostUsage{Screened: true, VerificationLevel: paymentgateway.VerificationLevelStandard}
+
+	tests := []struct {
+		name       string
+		req        *paymentgateway.PaymentRequest
+		status     string
+		usage      paymentgateway.CostUsage
+		rateCardID string
+		pspFee     float64
+		screening  float64
+		bureau     float64
+	}{
+		{
+			name:       "comissão fixa mais percentagem",
+			req:        costRequest("tx-1", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 10000),
+			status:     approved,
+			rateCardID: "ao-aoa",
+			pspFee:     200,
+		},
+		{
+			name:       "comissão mínima",
+			req:        costRequest("tx-2", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 1000),
+			status:     approved,
+			rateCardID: "ao-aoa",
+			pspFee:     100,
+		},
+		{
+			name:       "comissão máxima",
+			req:        costRequest("tx-3", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 1000000),
+			status:     approved,
+			rateCardID: "ao-aoa",
+			pspFee:     5000,
+		},
+		{
+			name:       "método sem tarifa própria usa a tarifa genérica",
+			req:        costRequest("tx-4", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodBankTransfer, 10000),
+			status:     approved,
+			rateCardID: "ao-aoa",
+			pspFee:     100,
+		},
+		{
+			name:       "transação recusada não paga comissão do PSP",
+			req:        costRequest("tx-5", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 10000),
+			status:     paymentgateway.TransactionStatusDenied,
+			usage:      paymentgateway.CostUsage{Screened: true, VerificationLevel: paymentgateway.VerificationLevelStandard, ExtraChecks: 2, BureauConsulted: true},
+			rateCardID: "ao-aoa",
+			screening:  30,
+			bureau:     30,
+		},
+		{
+			name:       "nível de verificação sem tarifa própria",
+			req:        costRequest("tx-6", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 10000),
+			status:     approved,
+			usage:      paymentgateway.CostUsage{Screened: true, VerificationLevel: paymentgateway.VerificationLevelPremium},
+			rateCardID: "ao-aoa",
+			pspFee:     200,
+			screening:  10,
+		},
+		{
+			name:       "moeda sem tabela própria usa a tabela do mercado",
+			req:        costRequest("tx-7", "merchant-1", paymentgateway.RegionAngola, "USD", paymentgateway.PaymentMethodCard, 1000),
+			status:     approved,
+			usage:      standard,
+			rateCardID: "ao-any",
+			pspFee:     20,
+		},
+		{
+			name:       "mercado sem tabela própria usa a tabela global",
+			req:        costRequest("tx-8", "merchant-1", paymentgateway.RegionBrazil, "BRL", paymentgateway.PaymentMethodPIX, 1000),
+			status:     approved,
+			rateCardID: "global",
+			pspFee:     30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost := service.Estimate(tt.req, tt.status, tt.usage)
+			assert.Equal(t, tt.rateCardID, cost.RateCardID)
+			assert.Equal(t, tt.pspFee, cost.PSPFee)
+			assert.Equal(t, tt.screening, cost.ScreeningFee)
+			assert.Equal(t, tt.bureau, cost.BureauFee)
+			assert.Equal(t, tt.pspFee+tt.screening+tt.bureau, cost.TotalCost)
+
+			var total float64
+			for _, component := range cost.Components {
+				total += component.Amount
+			}
+			assert.Equal(t, cost.TotalCost, total)
+		})
+	}
+
+	// Sem tabela aplicável o custo fica a zero
+	local, err := paymentgateway.NewCostAccountingService(paymentgateway.CostAccountingConfig{
+		RateCards: testRateCards()[2:],
+	}, paymentgateway.NewInMemoryCostStore())
+	require.NoError(t, err)
+	cost := local.Estimate(costRequest("tx-9", "merchant-1", paymentgateway.RegionMozambique, "MZN", paymentgateway.PaymentMethodCard, 1000), approved, standard)
+	assert.Empty(t, cost.RateCardID)
+	assert.Zero(t, cost.TotalCost)
+	assert.Empty(t, cost.Components)
+}
+
+func TestCostAttributionInvalidRateCards(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []paymentgateway.CostRateCard
+	}{
+		{
+			name:  "sem identificação",
+			cards: []paymentgateway.CostRateCard{{RegionCode: "*", Currency: "*"}},
+		},
+		{
+			name: "tarifa negativa",
+			cards: []paymentgateway.CostRateCard{{
+				RateCardID: "ao", RegionCode: paymentgateway.RegionAngola, Currency: "AOA",
+				PSPRates: map[string]paymentgateway.CostRate{"*": {PercentFee: -1}},
+			}},
+		},
+		{
+			name: "máximo inferior ao mínimo",
+			cards: []paymentgateway.CostRateCard{{
+				RateCardID: "ao", RegionCode: paymentgateway.RegionAngola, Currency: "AOA",
+				ScreeningRates: map[string]paymentgateway.CostRate{"*": {MinFee: 10, MaxFee: 5}},
+			}},
+		},
+		{
+			name: "tabela duplicada para o mesmo mercado e moeda",
+			cards: []paymentgateway.CostRateCard{
+				{RateCardID: "ao-1", RegionCode: paymentgateway.RegionAngola, Currency: "AOA"},
+				{RateCardID: "ao-2", RegionCode: paymentgateway.RegionAngola, Currency: "AOA"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := paymentgateway.NewCostAccountingService(paymentgateway.CostAccountingConfig{
+				RateCards: tt.cards,
+			}, paymentgateway.NewInMemoryCostStore())
+			assert.ErrorIs(t, err, paymentgateway.ErrCostRateCardInvalid)
+		})
+	}
+}
+
+func TestCostAttributionReport(t *testing.T) {
+	ctx := context.Background()
+	service, err := paymentgateway.NewCostAccountingService(paymentgateway.CostAccountingConfig{
+		RateCards: testRateCards(),
+	}, paymentgateway.NewInMemoryCostStore())
+	require.NoError(t, err)
+	clock := newTestClock(time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+
+	record := func(req *paymentgateway.PaymentRequest, status string, usage paymentgateway.CostUsage) {
+		service.RecordPaymentCost(ctx, req, &paymentgateway.PaymentResponse{Status: status}, usage)
+	}
+	record(costRequest("tx-1", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 10000),
+		paymentgateway.TransactionStatusApproved,
+		paymentgateway.CostUsage{Screened: true, VerificationLevel: paymentgateway.VerificationLevelStandard})
+	record(costRequest("tx-2", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 1000),
+		paymentgateway.TransactionStatusDenied,
+		paymentgateway.CostUsage{Screened: true, VerificationLevel: paymentgateway.VerificationLevelStandard, ExtraChecks: 1})
+	record(costRequest("tx-3", "merchant-1", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 1000),
+		paymentgateway.TransactionStatusPending, paymentgateway.CostUsage{})
+
+	clock.Set(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	record(costRequest("tx-4", "merchant-2", paymentgateway.RegionAngola, "AOA", paymentgateway.PaymentMethodCard, 10000),
+		paymentgateway.TransactionStatusApproved, paymentgateway.CostUsage{})
+
+	cost, err := service.GetTransactionCost(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, 220.0, cost.TotalCost)
+	require.Len(t, cost.Components, 2)
+	assert.Equal(t, paymentgateway.CostComponentPSP, cost.Components[0].Type)
+	assert.Equal(t, paymentgateway.PaymentMethodCard, cost.Components[0].Basis)
+	assert.Equal(t, paymentgateway.CostComponentScreening, cost.Components[1].Type)
+
+	// Pagamentos pendentes ficam por registar e os custos não são visíveis a outros tenants
+	_, err = service.GetTransactionCost(ctx, "tenant-1", "tx-3")
+	assert.ErrorIs(t, err, paymentgateway.ErrTransactionCostNotFound)
+	_, err = service.GetTransactionCost(ctx, "tenant-2", "tx-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrTransactionCostNotFound)
+
+	report, err := service.CostReport(ctx, "tenant-1", paymentgateway.CostReportFilter{From: "2026-10-16", To: "2026-10-16"})
+	require.NoError(t, err)
+	require.Len(t, report.Summaries, 1)
+	summary := report.Summaries[0]
+	assert.Equal(t, "merchant-1", summary.MerchantID)
+	assert.Equal(t, int64(2), summary.TransactionCount)
+	assert.Equal(t, 10000.0, summary.ApprovedVolume)
+	assert.Equal(t, 200.0, summary.PSPFees)
+	assert.Equal(t, 45.0, summary.ScreeningFees)
+	assert.Equal(t, 245.0, summary.TotalCost)
+	assert.Equal(t, 122.5, summary.CostPerTransaction)
+	assert.Equal(t, 245.0, summary.CostRateBps)
+
+	report, err = service.CostReport(ctx, "tenant-1", paymentgateway.CostReportFilter{
+		From: "2026-10-16", To: "2026-10-17", MerchantID: "merchant-2",
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Summaries, 1)
+	assert.Equal(t, 200.0, report.Summaries[0].TotalCost)
+
+	report, err = service.CostReport(ctx, "tenant-2", paymentgateway.CostReportFilter{From: "2026-10-16", To: "2026-10-17"})
+	require.NoError(t, err)
+	assert.Empty(t, report.Summaries)
+
+	for _, filter := range []paymentgateway.CostReportFilter{
+		{From: "2026-10-17", To: "2026-10-16"},
+		{From: "16/10/2026", To: "2026-10-17"},
+		{From: "2026-10-16"},
+	} {
+		_, err := service.CostReport(ctx, "tenant-1", filter)
+		assert.ErrorIs(t, err, paymentgateway.ErrCostReportFilterInvalid)
+	}
+}
@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+// storedSummaryObject é um ficheiro gravado no armazenamento de exportações
+type storedSummaryObject struct {
+	body        []byte
+	contentType string
+	metadata    map[string]string
+}
+
+// memorySummaryStorage guarda em memória os ficheiros exportados
+type memorySummaryStorage struct {
+	mutex   sync.Mutex
+	objects map[string]storedSummaryObject
+}
+
+func (s *memorySummaryStorage) PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.objects[key] = storedSummaryObject{body: body, contentType: contentType, metadata: metadata}
+	return nil
+}
+
+func (s *memorySummaryStorage) object(key string) (storedSummaryObject, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	object, ok := s.objects[key]
+	return object, ok
+}
+
+func newDailySummaryService(t *testing.T, config paymentgateway.DailySummaryConfig) (*paymentgateway.DailySummaryService, *memorySummaryStorage, *testClock) {
+	t.Helper()
+
+	storage := &memorySummaryStorage{objects: make(map[string]storedSummaryObject)}
+	config.TimeZone = "Africa/Luanda"
+	service, err := paymentgateway.NewDailySummaryService(config, paymentgateway.NewInMemoryDailySummaryStore(), storage)
+	require.NoError(t, err)
+
+	clock := newTestClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+	return service, storage, clock
+}
+
+// recordPayment regista o resultado de um pagamento no instante indicado
+func recordPayment(service *paymentgateway.DailySummaryService, clock *testClock, at time.Time, merchantID, currency, status string, amount float64) {
+	clock.Set(at)
+	service.RecordPaymentOutcome(context.Background(), &paymentgateway.PaymentRequest{
+		TenantID:      "tenant-1",
+		RegionCode:    paymentgateway.RegionAngola,
+		MerchantID:    merchantID,
+		TransactionID: "tx-" + merchantID + "-" + at.Format("150405"),
+		Amount:        amount,
+		Currency:      currency,
+	}, &paymentgateway.PaymentResponse{Status: status})
+}
+
+func TestDailySummaryCloseDayAggregation(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newDailySummaryService(t, paymentgateway.DailySummaryConfig{})
+
+	// 23:30 UTC de 15/10 já é 00:30 de 16/10 em Luanda; 22:30 UTC fica no dia anterior
+	day := time.Date(2026, 10, 15, 23, 30, 0, 0, time.UTC)
+	recordPayment(service, clock, day, "merchant-1", "AOA", paymentgateway.TransactionStatusApproved, 1000)
+	recordPayment(service, clock, day.Add(time.Minute), "merchant-1", "AOA", paymentgateway.TransactionStatusApproved, 2500.5)
+	recordPayment(service, clock, day.Add(2*time.Minute), "merchant-1", "AOA", paymentgateway.TransactionStatusDenied, 300)
+	recordPayment(service, clock, day.Add(3*time.Minute), "merchant-1", "AOA", paymentgateway.TransactionStatusChallenged, 100)
+	recordPayment(service, clock, day.Add(4*time.Minute), "merchant-1", "AOA", paymentgateway.TransactionStatusError, 50)
+	recordPayment(service, clock, day.Add(5*time.Minute), "merchant-1", "AOA", paymentgateway.TransactionStatusPending, 75)
+	recordPayment(service, clock, day.Add(6*time.Minute), "merchant-1", "USD", paymentgateway.TransactionStatusApproved, 200)
+	recordPayment(service, clock, day.Add(7*time.Minute), "merchant-2", "AOA", paymentgateway.TransactionStatusApproved, 700)
+	recordPayment(service, clock, day.Add(-time.Hour), "merchant-1", "AOA", paymentgateway.TransactionStatusApproved, 9999)
+
+	clock.Set(day.Add(10 * time.Minute))
+	require.NoError(t, service.RecordRefund(ctx, &paymentgateway.LedgerEntry{
+		TenantID: "tenant-1", RegionCode: paymentgateway.RegionAngola, MerchantID: "merchant-1",
+		TransactionID: "tx-a", Amount: 500, Currency: "AOA",
+	}))
+	require.NoError(t, service.RecordDispute(ctx, &paymentgateway.LedgerEntry{
+		TenantID: "tenant-1", RegionCode: paymentgateway.RegionAngola, MerchantID: "merchant-1",
+		TransactionID: "tx-b", Amount: 250.25, Currency: "AOA",
+	}))
+
+	summaries, err := service.CloseDay(ctx, "2026-10-16")
+	require.NoError(t, err)
+	require.Len(t, summaries, 3)
+
+	main := summaries[0]
+	assert.Equal(t, "merchant-1", main.MerchantID)
+	assert.Equal(t, "AOA", main.Currency)
+	assert.Equal(t, "2026-10-16", main.SummaryDate)
+	assert.Equal(t, int64(5), main.TransactionCount)
+	assert.Equal(t, int64(2), main.ApprovedCount)
+	assert.Equal(t, int64(1), main.DeniedCount)
+	assert.Equal(t, int64(1), main.ChallengedCount)
+	assert.Equal(t, int64(1), main.ErrorCount)
+	assert.Equal(t, 0.4, main.ApprovalRate)
+	assert.Equal(t, 3500.5, main.ApprovedVolume)
+	assert.Equal(t, 500.0, main.RefundVolume)
+	assert.Equal(t, 250.25, main.DisputeVolume)
+	assert.Equal(t, 2750.25, main.NetVolume)
+
+	// Moedas diferentes do mesmo comerciante não são somadas
+	assert.Equal(t, "merchant-1", summaries[1].MerchantID)
+	assert.Equal(t, "USD", summaries[1].Currency)
+	assert.Equal(t, 200.0, summaries[1].ApprovedVolume)
+	assert.Equal(t, "merchant-2", summaries[2].MerchantID)
+
+	previous, err := service.CloseDay(ctx, "2026-10-15")
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	assert.Equal(t, 9999.0, previous[0].ApprovedVolume)
+
+	// Lançamentos recebidos depois do fecho entram quando o dia é fechado novamente
+	require.NoError(t, service.RecordRefund(ctx, &paymentgateway.LedgerEntry{
+		TenantID: "tenant-1", RegionCode: paymentgateway.RegionAngola, MerchantID: "merchant-2",
+		TransactionID: "tx-c", Amount: 100, Currency: "AOA", OccurredAt: day.Add(time.Hour),
+	}))
+	_, err = service.CloseDay(ctx, "2026-10-16")
+	require.NoError(t, err)
+	listed, err := service.ListSummaries(ctx, "tenant-1", paymentgateway.DailySummaryFilter{
+		From: "2026-10-15", To: "2026-10-16", MerchantID: "merchant-2",
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, 600.0, listed[0].NetVolume)
+
+	other, err := service.ListSummaries(ctx, "tenant-2", paymentgateway.DailySummaryFilter{From: "2026-10-15", To: "2026-10-16"})
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	invalid := []*paymentgateway.LedgerEntry{
+		{RegionCode: paymentgateway.RegionAngola, MerchantID: "merchant-1", TransactionID: "tx-d", Amount: 10, Currency: "AOA"},
+		{TenantID: "tenant-1", MerchantID: "merchant-1", TransactionID: "tx-d", Amount: 0, Currency: "AOA"},
+		{TenantID: "tenant-1", MerchantID: "merchant-1", TransactionID: "tx-d", Amount: 10},
+	}
+	for _, entry := range invalid {
+		assert.ErrorIs(t, service.RecordRefund(ctx, entry), paymentgateway.ErrLedgerEntryInvalid)
+	}
+
+	for _, filter := range []paymentgateway.DailySummaryFilter{
+		{From: "16/10/2026", To: "2026-10-16"},
+		{From: "2026-10-16", To: "2026-10-15"},
+	} {
+		_, err := service.ListSummaries(ctx, "tenant-1", filter)
+		assert.ErrorIs(t, err, paymentgateway.ErrDailySummaryFilterInvalid)
+	}
+	_, err = service.CloseDay(ctx, "ontem")
+	assert.ErrorIs(t, err, paymentgateway.ErrDailySummaryFilterInvalid)
+}
+
+func TestDailySummaryExport(t *testing.T) {
+	ctx := context.Background()
+	service, storage, clock := newDailySummaryService(t, paymentgateway.DailySummaryConfig{
+		ExportFormats: []string{paymentgateway.SummaryExportCSV, paymentgateway.SummaryExportParquet},
+		ExportPrefix:  "/daily-summaries/",
+	})
+
+	_, err := service.ExportDay(ctx, "tenant-1", "2026-10-16", paymentgateway.SummaryExportCSV)
+	assert.ErrorIs(t, err, paymentgateway.ErrDailySummaryNotClosed)
+
+	recordPayment(service, clock, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), "merchant-1", "AOA", paymentgateway.TransactionStatusApproved, 1000)
+	clock.Set(time.Date(2026, 10, 17, 0, 30, 0, 0, time.UTC))
+	_, err = service.CloseDay(ctx, "2026-10-16")
+	require.NoError(t, err)
+
+	// O fecho exporta um ficheiro por formato configurado
+	exports, err := service.ListExports(ctx, "tenant-1", "2026-10-16")
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	for _, export := range exports {
+		assert.True(t, strings.HasPrefix(export.ObjectKey, "daily-summaries/tenant-1/2026-10-16/daily-summary-"))
+		assert.True(t, strings.HasSuffix(export.ObjectKey, "."+export.Format))
+		assert.Equal(t, 1, export.SummaryCount)
+
+		object, ok := storage.object(export.ObjectKey)
+		require.True(t, ok)
+		sum := sha256.Sum256(object.body)
+		assert.Equal(t, hex.EncodeToString(sum[:]), export.SHA256)
+		assert.Equal(t, export.SHA256, object.metadata["sha256"])
+		assert.Equal(t, int64(len(object.body)), export.SizeBytes)
+
+		switch export.Format {
+		case paymentgateway.SummaryExportCSV:
+			assert.Equal(t, "text/csv", object.contentType)
+			records, err := csv.NewReader(bytes.NewReader(object.body)).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+			assert.Equal(t, "tenant_id", records[0][0])
+			assert.Equal(t, []string{"tenant-1", "2026-10-16", "AO", "merchant-1", "AOA", "1", "1"}, records[1][:7])
+			assert.Equal(t, "2026-10-17T00:30:00Z", records[1][len(records[1])-1])
+		case paymentgateway.SummaryExportParquet:
+			assert.Equal(t, "application/vnd.apache.parquet", object.contentType)
+			assert.True(t, bytes.HasPrefix(object.body, []byte("PAR1")))
+		}
+	}
+
+	_, err = service.ExportDay(ctx, "tenant-1", "2026-10-16", "xlsx")
+	assert.ErrorIs(t, err, paymentgateway.ErrSummaryExportFormat)
+
+	_, err = paymentgateway.NewDailySummaryService(paymentgateway.DailySummaryConfig{
+		ExportFormats: []string{paymentgateway.SummaryExportCSV},
+	}, paymentgateway.NewInMemoryDailySummaryStore(), nil)
+	assert.ErrorIs(t, err, paymentgateway.ErrSummaryExportDisabled)
+	_, err = paymentgateway.NewDailySummaryService(paymentgateway.DailySummaryConfig{
+		ExportFormats: []string{"xlsx"},
+	}, paymentgateway.NewInMemoryDailySummaryStore(), storage)
+	assert.ErrorIs(t, err, paymentgateway.ErrSummaryExportFormat)
+}
+
+func TestDailySummarySchedulerClosesAfterDelay(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newDailySummaryService(t, paymentgateway.DailySummaryConfig{
+		CloseDelay:  30 * time.Minute,
+		CheckPeriod: 5 * time.Millisecond,
+	})
+	recordPayment(service, clock, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), "merchant-1", "AOA", paymentgateway.TransactionStatusApproved, 1000)
+
+	closed := func() bool {
+		summaries, err := service.ListSummaries(ctx, "tenant-1", paymentgateway.DailySummaryFilter{From: "2026-10-16", To: "2026-10-16"})
+		require.NoError(t, err)
+		return len(summaries) > 0
+	}
+
+	// 00:10 em Luanda: o atraso após a meia-noite ainda não decorreu
+	clock.Set(time.Date(2026, 10, 16, 23, 10, 0, 0, time.UTC))
+	service.Start()
+	t.Cleanup(func() { _ = service.Stop() })
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, closed())
+
+	clock.Set(time.Date(2026, 10, 16, 23, 31, 0, 0, time.UTC))
+	assert.Eventually(t, closed, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,386 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+const networkTokenWebhookSecret = "segredo-visa"
+
+// memoryCardVault devolve os cartões do cofre registados no teste
+type memoryCardVault map[string]*paymentgateway.VaultCard
+
+func (v memoryCardVault) GetCard(ctx context.Context, tenantID, vaultTokenID string) (*paymentgateway.VaultCard, error) {
+	card, ok := v[vaultTokenID]
+	if !ok {
+		return nil, paymentgateway.ErrVaultCardNotFound
+	}
+	return card, nil
+}
+
+// fakeNetworkTokenClient simula os serviços de tokenização das redes
+type fakeNetworkTokenClient struct {
+	mutex         sync.Mutex
+	provisionErr  error
+	cryptogramErr error
+	provisioned   []*paymentgateway.NetworkTokenProvisionRequest
+	deleted       []string
+}
+
+func (c *fakeNetworkTokenClient) Provision(ctx context.Context, req *paymentgateway.NetworkTokenProvisionRequest) (*paymentgateway.NetworkTokenProvisionResult, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.provisioned = append(c.provisioned, req)
+	if c.provisionErr != nil {
+		return nil, c.provisionErr
+	}
+	return &paymentgateway.NetworkTokenProvisionResult{
+		TokenReferenceID:        fmt.Sprintf("tref-%d", len(c.provisioned)),
+		Status:                  paymentgateway.NetworkTokenStatusActive,
+		TokenLast4:              "9876",
+		TokenExpiryMonth:        12,
+		TokenExpiryYear:         2030,
+		PaymentAccountReference: "par-1",
+	}, nil
+}
+
+func (c *fakeNetworkTokenClient) RequestCryptogram(ctx context.Context, req *paymentgateway.NetworkCryptogramRequest) (*paymentgateway.NetworkCryptogram, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cryptogramErr != nil {
+		return nil, c.cryptogramErr
+	}
+	return &paymentgateway.NetworkCryptogram{
+		TokenValue:  "4895370000001234",
+		ExpiryMonth: 12,
+		ExpiryYear:  2030,
+		Cryptogram:  "crypto-" + req.TransactionID,
+		ECI:         "07",
+	}, nil
+}
+
+func (c *fakeNetworkTokenClient) DeleteToken(ctx context.Context, network, tokenReferenceID, reason string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.deleted = append(c.deleted, tokenReferenceID)
+	return nil
+}
+
+func (c *fakeNetworkTokenClient) provisionCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.provisioned)
+}
+
+func newNetworkTokenService(t *testing.T, providers ...string) (*paymentgateway.NetworkTokenService, *fakeNetworkTokenClient, *testClock) {
+	t.Helper()
+
+	config := paymentgateway.NetworkTokenConfig{Providers: make(map[string]paymentgateway.NetworkTokenProviderConfig)}
+	for _, network := range providers {
+		config.Providers[network] = paymentgateway.NetworkTokenProviderConfig{
+			TokenRequestorID: "trid-" + network,
+			WebhookSecret:    networkTokenWebhookSecret,
+		}
+	}
+	vault := memoryCardVault{
+		"vault-visa":       {PAN: "4111111111111111", ExpiryMonth: 10, ExpiryYear: 2029, CardholderName: "Ana Silva"},
+		"vault-mastercard": {PAN: "2221000000000009", ExpiryMonth: 10, ExpiryYear: 2029},
+		"vault-amex":       {PAN: "378282246310005", ExpiryMonth: 10, ExpiryYear: 2029},
+	}
+	client := &fakeNetworkTokenClient{}
+
+	service, err := paymentgateway.NewNetworkTokenService(config, paymentgateway.NewInMemoryNetworkTokenStore(), vault, client)
+	require.NoError(t, err)
+	clock := newTestClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+	return service, client, clock
+}
+
+func enrollCard(t *testing.T, service *paymentgateway.NetworkTokenService, vaultTokenID string) *paymentgateway.CardToken {
+	t.Helper()
+	card, err := service.EnrollCard(context.Background(), &paymentgateway.CardTokenEnrollRequest{
+		TenantID:     "tenant-1",
+		UserID:       "user-1",
+		VaultTokenID: vaultTokenID,
+	})
+	require.NoError(t, err)
+	return card
+}
+
+func TestNetworkTokenEnrollment(t *testing.T) {
+	ctx := context.Background()
+	service, client, _ := newNetworkTokenService(t, paymentgateway.CardNetworkVisa)
+
+	visa := enrollCard(t, service, "vault-visa")
+	assert.Equal(t, paymentgateway.CardNetworkVisa, visa.Network)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusActive, visa.Status)
+	assert.Equal(t, "1111", visa.PANLast4)
+	assert.Equal(t, "tref-1", visa.TokenReferenceID)
+	assert.Equal(t, "9876", visa.TokenLast4)
+	assert.Equal(t, 1, visa.ProvisioningAttempts)
+	require.Len(t, client.provisioned, 1)
+	assert.Equal(t, visa.CardTokenID, client.provisioned[0].ReferenceID)
+	assert.Equal(t, "4111111111111111", client.provisioned[0].PAN)
+
+	// Um cartão já registado é devolvido sem novo pedido à rede
+	again := enrollCard(t, service, "vault-visa")
+	assert.Equal(t, visa.CardTokenID, again.CardTokenID)
+	assert.Equal(t, 1, client.provisionCount())
+	_, err := service.EnrollCard(ctx, &paymentgateway.CardTokenEnrollRequest{TenantID: "tenant-1", UserID: "user-2", VaultTokenID: "vault-visa"})
+	assert.ErrorIs(t, err, paymentgateway.ErrCardTokenInvalid)
+
+	// Rede sem tokenização configurada: o cartão fica pendente sem chamar a rede
+	mastercard := enrollCard(t, service, "vault-mastercard")
+	assert.Equal(t, paymentgateway.CardNetworkMastercard, mastercard.Network)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusPending, mastercard.Status)
+	assert.Equal(t, 1, client.provisionCount())
+
+	amex := enrollCard(t, service, "vault-amex")
+	assert.Empty(t, amex.Network)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusIneligible, amex.Status)
+
+	_, err = service.EnrollCard(ctx, &paymentgateway.CardTokenEnrollRequest{TenantID: "tenant-1", UserID: "user-1", VaultTokenID: "vault-unknown"})
+	assert.ErrorIs(t, err, paymentgateway.ErrVaultCardNotFound)
+	_, err = service.EnrollCard(ctx, &paymentgateway.CardTokenEnrollRequest{TenantID: "tenant-1", VaultTokenID: "vault-visa"})
+	assert.ErrorIs(t, err, paymentgateway.ErrCardTokenInvalid)
+
+	cards, err := service.ListCardTokens(ctx, "tenant-1", "user-1")
+	require.NoError(t, err)
+	assert.Len(t, cards, 3)
+	_, err = service.GetCardToken(ctx, "tenant-2", visa.CardTokenID)
+	assert.ErrorIs(t, err, paymentgateway.ErrCardTokenNotFound)
+}
+
+func TestNetworkTokenProvisioningFailures(t *testing.T) {
+	ctx := context.Background()
+	service, client, _ := newNetworkTokenService(t, paymentgateway.CardNetworkVisa, paymentgateway.CardNetworkMastercard)
+
+	// Indisponibilidade da rede deixa o token pendente para nova tentativa
+	client.provisionErr = fmt.Errorf("%w: timeout", paymentgateway.ErrNetworkTokenUnavailable)
+	visa := enrollCard(t, service, "vault-visa")
+	assert.Equal(t, paymentgateway.NetworkTokenStatusPending, visa.Status)
+	assert.Empty(t, visa.TokenReferenceID)
+
+	client.provisionErr = nil
+	visa, err := service.RetryProvisioning(ctx, "tenant-1", visa.CardTokenID)
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusActive, visa.Status)
+	assert.Equal(t, 2, visa.ProvisioningAttempts)
+	assert.Empty(t, visa.StatusReason)
+
+	_, err = service.RetryProvisioning(ctx, "tenant-1", visa.CardTokenID)
+	assert.ErrorIs(t, err, paymentgateway.ErrNetworkTokenStateInvalid)
+
+	// Recusa da rede ou do emissor torna o cartão inelegível
+	client.provisionErr = fmt.Errorf("%w: card_not_eligible", paymentgateway.ErrNetworkTokenDeclined)
+	mastercard := enrollCard(t, service, "vault-mastercard")
+	assert.Equal(t, paymentgateway.NetworkTokenStatusIneligible, mastercard.Status)
+	_, err = service.RetryProvisioning(ctx, "tenant-1", mastercard.CardTokenID)
+	assert.ErrorIs(t, err, paymentgateway.ErrNetworkTokenStateInvalid)
+}
+
+func TestNetworkTokenAuthorizationCredential(t *testing.T) {
+	ctx := context.Background()
+	service, client, _ := newNetworkTokenService(t, paymentgateway.CardNetworkVisa)
+	visa := enrollCard(t, service, "vault-visa")
+	mastercard := enrollCard(t, service, "vault-mastercard")
+
+	payment := func(cardTokenID, userID string) *paymentgateway.PaymentRequest {
+		return &paymentgateway.PaymentRequest{
+			TransactionID: "tx-1",
+			TenantID:      "tenant-1",
+			UserID:        userID,
+			MerchantID:    "merchant-1",
+			CardTokenID:   cardTokenID,
+			Amount:        1000,
+			Currency:      "AOA",
+		}
+	}
+
+	credential, err := service.AuthorizationCredential(ctx, payment(visa.CardTokenID, "user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.CardCredentialNetworkToken, credential.Type)
+	assert.Equal(t, "tref-1", credential.TokenReferenceID)
+	assert.Equal(t, "crypto-tx-1", credential.Cryptogram)
+	assert.Equal(t, "07", credential.ECI)
+	assert.Empty(t, credential.VaultTokenID)
+	assert.Empty(t, credential.FallbackReason)
+
+	// Sem criptograma, a autorização usa o token do cofre
+	client.cryptogramErr = fmt.Errorf("%w: timeout", paymentgateway.ErrNetworkTokenUnavailable)
+	credential, err = service.AuthorizationCredential(ctx, payment(visa.CardTokenID, "user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.CardCredentialVaultToken, credential.Type)
+	assert.Equal(t, "vault-visa", credential.VaultTokenID)
+	assert.Equal(t, paymentgateway.NetworkTokenFallbackCryptogramFailure, credential.FallbackReason)
+	assert.Empty(t, credential.Cryptogram)
+	client.cryptogramErr = nil
+
+	credential, err = service.AuthorizationCredential(ctx, payment(mastercard.CardTokenID, "user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.CardCredentialVaultToken, credential.Type)
+	assert.Equal(t, paymentgateway.NetworkTokenFallbackNoToken, credential.FallbackReason)
+
+	_, err = service.AuthorizationCredential(ctx, payment(visa.CardTokenID, "user-2"))
+	assert.ErrorIs(t, err, paymentgateway.ErrCardTokenNotFound)
+
+	// Depois da eliminação o cartão continua utilizável através do cofre
+	deleted, err := service.DeleteNetworkToken(ctx, "tenant-1", visa.CardTokenID, "pedido do titular")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusDeleted, deleted.Status)
+	assert.Equal(t, []string{"tref-1"}, client.deleted)
+
+	credential, err = service.AuthorizationCredential(ctx, payment(visa.CardTokenID, "user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.CardCredentialVaultToken, credential.Type)
+	assert.Equal(t, paymentgateway.NetworkTokenFallbackNoToken, credential.FallbackReason)
+
+	// Um novo registo do cartão provisiona um novo token
+	reenrolled := enrollCard(t, service, "vault-visa")
+	assert.Equal(t, visa.CardTokenID, reenrolled.CardTokenID)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusActive, reenrolled.Status)
+	assert.Equal(t, "tref-2", reenrolled.TokenReferenceID)
+}
+
+// signedLifecycleEvent serializa e assina o evento como as redes o enviam
+func signedLifecycleEvent(t *testing.T, clock *testClock, secret string, event map[string]interface{}) ([]byte, string, string) {
+	t.Helper()
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+	timestamp := strconv.FormatInt(clock.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return payload, timestamp, "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestNetworkTokenLifecycleEvents(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newNetworkTokenService(t, paymentgateway.CardNetworkVisa)
+	card := enrollCard(t, service, "vault-visa")
+	base := clock.Now()
+
+	send := func(eventID, eventType string, occurredAt time.Time, extra map[string]interface{}) *paymentgateway.NetworkTokenLifecycleEvent {
+		t.Helper()
+		event := map[string]interface{}{
+			"event_id":           eventID,
+			"token_reference_id": card.TokenReferenceID,
+			"event_type":         eventType,
+			"reason":             "emissor",
+			"occurred_at":        occurredAt,
+		}
+		for key, value := range extra {
+			event[key] = value
+		}
+		payload, timestamp, signature := signedLifecycleEvent(t, clock, networkTokenWebhookSecret, event)
+		received, err := service.HandleLifecycleEvent(ctx, paymentgateway.CardNetworkVisa, payload, timestamp, signature)
+		require.NoError(t, err)
+		return received
+	}
+	status := func() string {
+		current, err := service.GetCardToken(ctx, "tenant-1", card.CardTokenID)
+		require.NoError(t, err)
+		return current.Status
+	}
+
+	assert.True(t, send("evt-1", paymentgateway.NetworkTokenEventSuspend, base.Add(time.Minute), nil).Applied)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusSuspended, status())
+
+	credential, err := service.AuthorizationCredential(ctx, &paymentgateway.PaymentRequest{
+		TransactionID: "tx-1", TenantID: "tenant-1", UserID: "user-1", CardTokenID: card.CardTokenID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.NetworkTokenFallbackTokenSuspended, credential.FallbackReason)
+
+	// Eventos anteriores ao último evento aplicado são ignorados
+	assert.False(t, send("evt-0", paymentgateway.NetworkTokenEventResume, base, nil).Applied)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusSuspended, status())
+
+	assert.True(t, send("evt-2", paymentgateway.NetworkTokenEventResume, base.Add(2*time.Minute), nil).Applied)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusActive, status())
+
+	assert.True(t, send("evt-3", paymentgateway.NetworkTokenEventUpdate, base.Add(3*time.Minute), map[string]interface{}{
+		"token_last4": "4321", "token_expiry_month": 6, "token_expiry_year": 2032,
+	}).Applied)
+	updated, err := service.GetCardToken(ctx, "tenant-1", card.CardTokenID)
+	require.NoError(t, err)
+	assert.Equal(t, "4321", updated.TokenLast4)
+	assert.Equal(t, 6, updated.TokenExpiryMonth)
+	assert.Equal(t, 2032, updated.TokenExpiryYear)
+
+	// Um token eliminado não volta a ser retomado
+	assert.True(t, send("evt-4", paymentgateway.NetworkTokenEventDelete, base.Add(4*time.Minute), nil).Applied)
+	assert.False(t, send("evt-5", paymentgateway.NetworkTokenEventResume, base.Add(5*time.Minute), nil).Applied)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusDeleted, status())
+
+	payload, timestamp, signature := signedLifecycleEvent(t, clock, networkTokenWebhookSecret, map[string]interface{}{
+		"event_id": "evt-6", "token_reference_id": "tref-unknown", "event_type": paymentgateway.NetworkTokenEventSuspend, "occurred_at": base,
+	})
+	event, err := service.HandleLifecycleEvent(ctx, paymentgateway.CardNetworkVisa, payload, timestamp, signature)
+	require.NoError(t, err)
+	assert.False(t, event.Applied)
+
+	payload, timestamp, signature = signedLifecycleEvent(t, clock, networkTokenWebhookSecret, map[string]interface{}{
+		"event_id": "evt-7", "token_reference_id": card.TokenReferenceID, "event_type": "reissue", "occurred_at": base,
+	})
+	_, err = service.HandleLifecycleEvent(ctx, paymentgateway.CardNetworkVisa, payload, timestamp, signature)
+	assert.ErrorIs(t, err, paymentgateway.ErrNetworkTokenEventInvalid)
+}
+
+func TestNetworkTokenLifecycleEventSignature(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newNetworkTokenService(t, paymentgateway.CardNetworkVisa)
+	card := enrollCard(t, service, "vault-visa")
+
+	event := map[string]interface{}{
+		"event_id":           "evt-1",
+		"token_reference_id": card.TokenReferenceID,
+		"event_type":         paymentgateway.NetworkTokenEventSuspend,
+		"occurred_at":        clock.Now(),
+	}
+	payload, timestamp, signature := signedLifecycleEvent(t, clock, networkTokenWebhookSecret, event)
+	_, _, forged := signedLifecycleEvent(t, clock, "outro-segredo", event)
+
+	tests := []struct {
+		name      string
+		network   string
+		payload   []byte
+		timestamp string
+		signature string
+		advance   time.Duration
+		err       error
+	}{
+		{"segredo errado", paymentgateway.CardNetworkVisa, payload, timestamp, forged, 0, paymentgateway.ErrNetworkTokenSignatureInvalid},
+		{"corpo alterado", paymentgateway.CardNetworkVisa, append([]byte(" "), payload...), timestamp, signature, 0, paymentgateway.ErrNetworkTokenSignatureInvalid},
+		{"sem assinatura", paymentgateway.CardNetworkVisa, payload, timestamp, "", 0, paymentgateway.ErrNetworkTokenSignatureInvalid},
+		{"carimbo inválido", paymentgateway.CardNetworkVisa, payload, "ontem", signature, 0, paymentgateway.ErrNetworkTokenSignatureInvalid},
+		{"carimbo fora da janela", paymentgateway.CardNetworkVisa, payload, timestamp, signature, 6 * time.Minute, paymentgateway.ErrNetworkTokenSignatureInvalid},
+		{"rede sem tokenização configurada", paymentgateway.CardNetworkMastercard, payload, timestamp, signature, 0, paymentgateway.ErrNetworkTokenProviderNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			defer clock.Advance(-tt.advance)
+			_, err := service.HandleLifecycleEvent(ctx, tt.network, tt.payload, tt.timestamp, tt.signature)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	current, err := service.GetCardToken(ctx, "tenant-1", card.CardTokenID)
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.NetworkTokenStatusActive, current.Status)
+}
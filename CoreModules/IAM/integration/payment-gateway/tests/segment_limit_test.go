@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+// failingSegmentLimitStore permite simular a indisponibilidade do armazenamento das matrizes
+type failingSegmentLimitStore struct {
+	*paymentgateway.InMemorySegmentLimitStore
+	mutex   sync.Mutex
+	failing bool
+}
+
+func (s *failingSegmentLimitStore) GetMatrix(ctx context.Context, tenantID string) (*paymentgateway.LimitMatrix, error) {
+	s.mutex.Lock()
+	failing := s.failing
+	s.mutex.Unlock()
+	if failing {
+		return nil, errors.New("base de dados indisponível")
+	}
+	return s.InMemorySegmentLimitStore.GetMatrix(ctx, tenantID)
+}
+
+func (s *failingSegmentLimitStore) setFailing(failing bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failing = failing
+}
+
+func newSegmentLimitService(t *testing.T, store paymentgateway.SegmentLimitStore) (*paymentgateway.SegmentLimitService, *time.Time) {
+	t.Helper()
+
+	service, err := paymentgateway.NewSegmentLimitService(paymentgateway.SegmentLimitConfig{CacheTTL: time.Minute}, store)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.SetClock(func() time.Time { return now })
+	return service, &now
+}
+
+func limitRule(paymentType, tier, category, market string, single float64) paymentgateway.SegmentLimitRule {
+	return paymentgateway.SegmentLimitRule{
+		PaymentType: paymentType,
+		Segment: paymentgateway.LimitSegment{
+			CustomerTier:     tier,
+			MerchantCategory: category,
+			Market:           market,
+		},
+		Limit: paymentgateway.TransactionLimit{SingleTransactionMax: single},
+	}
+}
+
+func TestSegmentLimitResolutionOrder(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newSegmentLimitService(t, paymentgateway.NewInMemorySegmentLimitStore())
+
+	_, err := service.ReplaceMatrix(ctx, "tenant-1", []paymentgateway.SegmentLimitRule{
+		limitRule("*", "", "", "", 1000),
+		limitRule("*", "gold", "electronics", "AO", 9000),
+		limitRule(paymentgateway.LimitPaymentTypeStandard, "", "", "", 2000),
+		limitRule(paymentgateway.LimitPaymentTypeStandard, "", "", "ao", 3000),
+		limitRule(paymentgateway.LimitPaymentTypeStandard, "", "retail", "", 2500),
+		limitRule(paymentgateway.LimitPaymentTypeStandard, "Gold", "", "AO", 5000),
+		limitRule(paymentgateway.LimitPaymentTypeHighRisk, "", "", "", 500),
+	}, 0, "risk-admin", "matriz inicial")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		tenantID    string
+		paymentType string
+		tier        string
+		category    string
+		market      string
+		expected    float64
+	}{
+		{"regra com mais dimensões do segmento", "tenant-1", paymentgateway.LimitPaymentTypeStandard, "gold", "electronics", "AO", 5000},
+		{"empate resolvido pela regra mais restritiva", "tenant-1", paymentgateway.LimitPaymentTypeStandard, "silver", "retail", "AO", 2500},
+		{"regra do mercado", "tenant-1", paymentgateway.LimitPaymentTypeStandard, "silver", "electronics", "AO", 3000},
+		{"mercado sem distinção de maiúsculas", "tenant-1", paymentgateway.LimitPaymentTypeStandard, "silver", "electronics", "ao", 3000},
+		{"regra geral do tipo de pagamento", "tenant-1", paymentgateway.LimitPaymentTypeStandard, "silver", "electronics", "US", 2000},
+		{"tipo de pagamento prevalece sobre o segmento", "tenant-1", paymentgateway.LimitPaymentTypeHighRisk, "gold", "electronics", "AO", 500},
+		{"curinga do segmento sem regra do tipo", "tenant-1", paymentgateway.LimitPaymentTypeRecurring, "gold", "electronics", "AO", 9000},
+		{"curinga geral", "tenant-1", paymentgateway.LimitPaymentTypeInternational, "silver", "retail", "US", 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := service.Resolve(ctx, paymentgateway.LimitResolutionRequest{
+				TenantID:         tt.tenantID,
+				PaymentType:      tt.paymentType,
+				CustomerTier:     tt.tier,
+				MerchantCategory: tt.category,
+				Market:           tt.market,
+			})
+			require.NoError(t, err)
+			require.NotNil(t, resolved)
+			assert.Equal(t, tt.expected, resolved.Limit.SingleTransactionMax)
+			assert.Equal(t, int64(1), resolved.MatrixVersion)
+			assert.NotEmpty(t, resolved.RuleID)
+		})
+	}
+
+	// Tenants sem matriz não têm limite resolvido
+	resolved, err := service.Resolve(ctx, paymentgateway.LimitResolutionRequest{
+		TenantID:    "tenant-2",
+		PaymentType: paymentgateway.LimitPaymentTypeStandard,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resolved)
+}
+
+func TestSegmentLimitCheckLimitsCurrency(t *testing.T) {
+	ctx := context.Background()
+	connector, err := paymentgateway.NewBureauPaymentGatewayConnector(paymentgateway.BureauPaymentGatewayConfig{
+		TransactionLimits: map[string]paymentgateway.TransactionLimit{
+			"standard_AO": {SingleTransactionMax: 500000},
+			"standard":    {SingleTransactionMax: 1000},
+		},
+	})
+	require.NoError(t, err)
+	service, _ := newSegmentLimitService(t, paymentgateway.NewInMemorySegmentLimitStore())
+	connector.SetSegmentLimitService(service)
+
+	_, err = service.CreateRule(ctx, "tenant-1", limitRule(paymentgateway.LimitPaymentTypeStandard, "", "", "US", 5000), "risk-admin", "")
+	require.NoError(t, err)
+
+	// Os limites são expressos na moeda do mercado a que se aplicam
+	tests := []struct {
+		name     string
+		market   string
+		currency string
+		amount   float64
+		allowed  bool
+		details  string
+	}{
+		{"matriz do segmento prevalece sobre o limite global", paymentgateway.RegionUSA, "USD", 4000, true, ""},
+		{"limite da matriz excedido", paymentgateway.RegionUSA, "USD", 6000, false, "Valor da transação (6000.00 USD) excede o limite permitido (5000.00 USD)"},
+		{"limite regional na moeda do mercado", paymentgateway.RegionAngola, "AOA", 450000, true, ""},
+		{"limite regional excedido", paymentgateway.RegionAngola, "AOA", 600000, false, "Valor da transação (600000.00 AOA) excede o limite permitido (500000.00 AOA)"},
+		{"limite global do tipo de pagamento", paymentgateway.RegionMozambique, "MZN", 1500, false, "Valor da transação (1500.00 MZN) excede o limite permitido (1000.00 MZN)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := connector.CheckLimits(ctx, &paymentgateway.PaymentRequest{
+				RequestID:  "req-1",
+				TenantID:   "tenant-1",
+				RegionCode: tt.market,
+				Currency:   tt.currency,
+				Amount:     tt.amount,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, result.Allowed)
+			if !tt.allowed {
+				assert.Equal(t, "single_transaction_limit_exceeded", result.Reason)
+				assert.Equal(t, tt.details, result.Details)
+			}
+		})
+	}
+}
+
+func TestSegmentLimitMatrixChangesAndAudit(t *testing.T) {
+	ctx := context.Background()
+	service, now := newSegmentLimitService(t, paymentgateway.NewInMemorySegmentLimitStore())
+
+	created, err := service.CreateRule(ctx, "tenant-1", limitRule("", "", "", "ao", 3000), "risk-admin", "mercado angolano")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.SegmentWildcard, created.PaymentType)
+	assert.Equal(t, "AO", created.Segment.Market)
+	assert.Equal(t, "risk-admin", created.UpdatedBy)
+
+	other, err := service.CreateRule(ctx, "tenant-1", limitRule(paymentgateway.LimitPaymentTypeStandard, "", "", "", 2000), "risk-admin", "")
+	require.NoError(t, err)
+
+	*now = now.Add(time.Hour)
+	update := limitRule("*", "", "", "AO", 3500)
+	updated, err := service.UpdateRule(ctx, "tenant-1", created.RuleID, update, "compliance", "revisão trimestral")
+	require.NoError(t, err)
+	assert.Equal(t, 3500.0, updated.Limit.SingleTransactionMax)
+
+	// Regras inalteradas mantêm o autor e a data da última alteração
+	matrix, err := service.GetMatrix(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), matrix.Version)
+	for _, rule := range matrix.Rules {
+		if rule.RuleID == other.RuleID {
+			assert.Equal(t, "risk-admin", rule.UpdatedBy)
+			assert.Equal(t, now.Add(-time.Hour), rule.UpdatedAt)
+		}
+	}
+
+	require.NoError(t, service.DeleteRule(ctx, "tenant-1", other.RuleID, "compliance", ""))
+	assert.ErrorIs(t, service.DeleteRule(ctx, "tenant-1", other.RuleID, "compliance", ""), paymentgateway.ErrLimitRuleNotFound)
+	_, err = service.UpdateRule(ctx, "tenant-1", "lim-inexistente", update, "compliance", "")
+	assert.ErrorIs(t, err, paymentgateway.ErrLimitRuleNotFound)
+
+	// Substituição com versão desatualizada é recusada
+	_, err = service.ReplaceMatrix(ctx, "tenant-1", nil, 3, "compliance", "")
+	assert.ErrorIs(t, err, paymentgateway.ErrLimitMatrixVersionConflict)
+	replaced, err := service.ReplaceMatrix(ctx, "tenant-1", nil, 4, "compliance", "limpeza")
+	require.NoError(t, err)
+	assert.Empty(t, replaced.Rules)
+
+	events, err := service.AuditEvents(ctx, "tenant-1", time.Time{}, now.Add(time.Hour))
+	require.NoError(t, err)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{
+		paymentgateway.LimitEventRuleCreated,
+		paymentgateway.LimitEventRuleCreated,
+		paymentgateway.LimitEventRuleUpdated,
+		paymentgateway.LimitEventRuleDeleted,
+		paymentgateway.LimitEventRuleDeleted,
+		paymentgateway.LimitEventMatrixReplaced,
+	}, types)
+	assert.Equal(t, 3000.0, events[2].Before.Limit.SingleTransactionMax)
+	assert.Equal(t, 3500.0, events[2].After.Limit.SingleTransactionMax)
+	assert.Equal(t, "revisão trimestral", events[2].Reason)
+	assert.Nil(t, events[3].After)
+
+	invalid := []struct {
+		name  string
+		rule  paymentgateway.SegmentLimitRule
+		actor string
+	}{
+		{"tipo de pagamento desconhecido", limitRule("wire", "", "", "", 100), "risk-admin"},
+		{"limite negativo", limitRule("*", "", "", "", -1), "risk-admin"},
+		{"limite por transação acima do diário", paymentgateway.SegmentLimitRule{
+			PaymentType: "*",
+			Limit:       paymentgateway.TransactionLimit{SingleTransactionMax: 200, DailyTransactionMax: 100},
+		}, "risk-admin"},
+		{"limite diário acima do mensal", paymentgateway.SegmentLimitRule{
+			PaymentType: "*",
+			Limit:       paymentgateway.TransactionLimit{DailyTransactionMax: 200, MonthlyTransactionMax: 100},
+		}, "risk-admin"},
+		{"sem ator", limitRule("*", "", "", "", 100), " "},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateRule(ctx, "tenant-1", tt.rule, tt.actor, "")
+			assert.ErrorIs(t, err, paymentgateway.ErrLimitRuleInvalid)
+		})
+	}
+
+	// Duas regras para o mesmo tipo e segmento são ambíguas
+	_, err = service.ReplaceMatrix(ctx, "tenant-1", []paymentgateway.SegmentLimitRule{
+		limitRule("standard", "", "", "AO", 100),
+		limitRule("STANDARD", "*", "", "ao", 200),
+	}, 5, "compliance", "")
+	assert.ErrorIs(t, err, paymentgateway.ErrLimitRuleInvalid)
+}
+
+func TestSegmentLimitCacheAndStoreFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &failingSegmentLimitStore{InMemorySegmentLimitStore: paymentgateway.NewInMemorySegmentLimitStore()}
+	resolver, now := newSegmentLimitService(t, store)
+	admin, _ := newSegmentLimitService(t, store)
+
+	rule, err := admin.CreateRule(ctx, "tenant-1", limitRule("*", "", "", "", 1000), "risk-admin", "")
+	require.NoError(t, err)
+
+	request := paymentgateway.LimitResolutionRequest{TenantID: "tenant-1", PaymentType: paymentgateway.LimitPaymentTypeStandard}
+	resolved, err := resolver.Resolve(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, resolved.Limit.SingleTransactionMax)
+
+	// Alterações feitas por outra instância só são vistas depois da validade da cache
+	_, err = admin.UpdateRule(ctx, "tenant-1", rule.RuleID, limitRule("*", "", "", "", 800), "risk-admin", "")
+	require.NoError(t, err)
+	resolved, err = resolver.Resolve(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, resolved.Limit.SingleTransactionMax)
+
+	*now = now.Add(time.Minute)
+	resolved, err = resolver.Resolve(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, 800.0, resolved.Limit.SingleTransactionMax)
+	assert.Equal(t, int64(2), resolved.MatrixVersion)
+
+	// Com o armazenamento indisponível a última matriz conhecida continua a ser usada
+	store.setFailing(true)
+	*now = now.Add(time.Hour)
+	resolved, err = resolver.Resolve(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, 800.0, resolved.Limit.SingleTransactionMax)
+
+	// Sem matriz em cache a falha é propagada
+	resolver.InvalidateCache("")
+	_, err = resolver.Resolve(ctx, request)
+	assert.Error(t, err)
+}
@@ -0,0 +1,460 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cv "github.com/innovabizdevops/innovabiz-iam/integration/cross-verification"
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+const stepUpCallbackSecret = "segredo-das-notificacoes"
+
+// fakeIdentityStepUpClient emite desafios numerados e regista os pedidos e cancelamentos recebidos
+type fakeIdentityStepUpClient struct {
+	mutex         sync.Mutex
+	err           error
+	expiresAt     time.Time
+	requests      []*paymentgateway.StepUpChallengeRequest
+	cancellations map[string]string
+}
+
+func (c *fakeIdentityStepUpClient) CreateChallenge(ctx context.Context, req *paymentgateway.StepUpChallengeRequest) (*paymentgateway.StepUpChallenge, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.requests = append(c.requests, req)
+	return &paymentgateway.StepUpChallenge{
+		ChallengeID:     fmt.Sprintf("chl-%d", len(c.requests)),
+		Method:          "push",
+		Instructions:    "Aprove o pedido na aplicação",
+		VerificationURL: "https://identity.example/challenges",
+		MaxAttempts:     3,
+		ExpiresAt:       c.expiresAt,
+	}, nil
+}
+
+func (c *fakeIdentityStepUpClient) CancelChallenge(ctx context.Context, tenantID, challengeID, reason string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cancellations == nil {
+		c.cancellations = make(map[string]string)
+	}
+	c.cancellations[challengeID] = reason
+	return nil
+}
+
+// cancellation retorna o motivo do cancelamento do desafio, vazio se não foi cancelado
+func (c *fakeIdentityStepUpClient) cancellation(challengeID string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.cancellations[challengeID]
+}
+
+// failingStepUpStore simula a indisponibilidade do armazenamento na criação do step-up
+type failingStepUpStore struct {
+	*paymentgateway.InMemoryPaymentStepUpStore
+}
+
+func (failingStepUpStore) CreateStepUp(ctx context.Context, stepUp *paymentgateway.PaymentStepUp) error {
+	return errors.New("base de dados indisponível")
+}
+
+// stepUpVerifier aprova a verificação cruzada com a pontuação configurada
+type stepUpVerifier struct {
+	score int
+}
+
+func (v stepUpVerifier) Verify(ctx context.Context, req *cv.CredentialFinancialVerificationRequest) (*cv.VerificationResult, error) {
+	return &cv.VerificationResult{
+		Category:       cv.CategoryIdentity,
+		Status:         cv.VerificationStatusPassed,
+		Score:          v.score,
+		VerifiedFields: []string{"full_name"},
+	}, nil
+}
+
+func (stepUpVerifier) GetCategory() string { return cv.CategoryIdentity }
+
+func (stepUpVerifier) GetWeight() int { return 1 }
+
+func newStepUpService(t *testing.T, store paymentgateway.PaymentStepUpStore, client *fakeIdentityStepUpClient) (*paymentgateway.StepUpService, *paymentgateway.BureauPaymentGatewayConnector, *testClock) {
+	t.Helper()
+
+	connector, err := paymentgateway.NewBureauPaymentGatewayConnector(paymentgateway.BureauPaymentGatewayConfig{})
+	require.NoError(t, err)
+
+	service, err := paymentgateway.NewStepUpService(paymentgateway.StepUpConfig{
+		CallbackURL:    "https://gateway.example/step-up/callback",
+		CallbackSecret: stepUpCallbackSecret,
+	}, store, client, connector)
+	require.NoError(t, err)
+
+	clock := newTestClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+	connector.SetStepUpService(service)
+	return service, connector, clock
+}
+
+func stepUpRequest(transactionID string) *paymentgateway.PaymentRequest {
+	return &paymentgateway.PaymentRequest{
+		RequestID:     "req-" + transactionID,
+		TransactionID: transactionID,
+		TenantID:      "tenant-1",
+		UserID:        "user-1",
+		MerchantID:    "merchant-1",
+		RegionCode:    paymentgateway.RegionAngola,
+		Amount:        1000,
+		Currency:      "AOA",
+		PaymentMethod: paymentgateway.PaymentMethodCard,
+		Timestamp:     time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// beginStepUp suspende o pagamento da transação à espera do desafio e retorna a resposta desafiada
+func beginStepUp(t *testing.T, service *paymentgateway.StepUpService, transactionID string) *paymentgateway.PaymentResponse {
+	t.Helper()
+
+	req := stepUpRequest(transactionID)
+	response := &paymentgateway.PaymentResponse{
+		RequestID:     req.RequestID,
+		TransactionID: transactionID,
+		Status:        paymentgateway.TransactionStatusChallenged,
+		StatusCode:    paymentgateway.StepUpRequiredStatusCode,
+		TrustScore:    70,
+		RiskLevel:     "medium",
+	}
+	require.NoError(t, service.Begin(context.Background(), req, response, nil, paymentgateway.CostUsage{}))
+	return response
+}
+
+// signedStepUpCallback serializa e assina a notificação como o serviço de identidade
+func signedStepUpCallback(t *testing.T, secret string, signedAt time.Time, callback paymentgateway.StepUpCallback) ([]byte, string, string) {
+	t.Helper()
+
+	payload, err := json.Marshal(callback)
+	require.NoError(t, err)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(payload)))
+	return payload, timestamp, "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func stepUpCallback(challengeID, outcome string) paymentgateway.StepUpCallback {
+	return paymentgateway.StepUpCallback{
+		ChallengeID: challengeID,
+		TenantID:    "tenant-1",
+		UserID:      "user-1",
+		Outcome:     outcome,
+		MFALevel:    "high",
+	}
+}
+
+func TestStepUpRequiredRiskLevels(t *testing.T) {
+	service, _, _ := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), &fakeIdentityStepUpClient{})
+
+	// Sem níveis configurados apenas o risco médio exige step-up
+	assert.True(t, service.Requires("medium"))
+	assert.False(t, service.Requires("low"))
+	assert.False(t, service.Requires("high"))
+}
+
+func TestStepUpBeginSuspendsPayment(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeIdentityStepUpClient{}
+	service, _, clock := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), client)
+
+	response := beginStepUp(t, service, "tx-1")
+
+	require.Len(t, client.requests, 1)
+	challengeReq := client.requests[0]
+	assert.Equal(t, "tenant-1", challengeReq.TenantID)
+	assert.Equal(t, "user-1", challengeReq.UserID)
+	assert.Equal(t, "tx-1", challengeReq.Reference)
+	assert.Equal(t, "payment_risk_medium", challengeReq.Reason)
+	assert.Equal(t, "high", challengeReq.RequiredMFALevel)
+	assert.Equal(t, "https://gateway.example/step-up/callback", challengeReq.CallbackURL)
+	assert.Equal(t, clock.Now().Add(5*time.Minute), challengeReq.ExpiresAt)
+
+	assert.True(t, response.ChallengeRequired)
+	require.NotNil(t, response.ChallengeDetails)
+	assert.Equal(t, "chl-1", response.ChallengeDetails.ChallengeID)
+	assert.Equal(t, "mfa_step_up", response.ChallengeDetails.ChallengeType)
+	assert.Equal(t, "push", response.ChallengeDetails.ChallengeMethod)
+	assert.Equal(t, 3, response.ChallengeDetails.MaxRetries)
+	assert.Equal(t, "/payments/tx-1/step-up", response.ChallengeDetails.ChallengeMetadata["status_url"])
+	assert.Equal(t, "high", response.ChallengeDetails.ChallengeMetadata["required_mfa_level"])
+
+	stepUp, err := service.GetStepUp(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.StepUpStatusPending, stepUp.Status)
+	assert.Equal(t, "chl-1", stepUp.ChallengeID)
+	assert.Equal(t, response.ChallengeDetails.ChallengeMetadata["step_up_id"], stepUp.StepUpID)
+	assert.Equal(t, clock.Now().Add(5*time.Minute), stepUp.ExpiresAt)
+
+	// O step-up de outro tenant não é visível
+	_, err = service.GetStepUp(ctx, "tenant-2", "tx-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrStepUpNotFound)
+
+	// O prazo mais curto do serviço de identidade prevalece
+	client.expiresAt = clock.Now().Add(2 * time.Minute)
+	response = beginStepUp(t, service, "tx-2")
+	assert.Equal(t, client.expiresAt, response.ChallengeDetails.ExpirationTime)
+}
+
+func TestStepUpBeginFailures(t *testing.T) {
+	// Sem desafio emitido o erro do serviço de identidade é propagado
+	client := &fakeIdentityStepUpClient{err: paymentgateway.ErrStepUpUnavailable}
+	service, _, _ := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), client)
+	req := stepUpRequest("tx-1")
+	err := service.Begin(context.Background(), req, &paymentgateway.PaymentResponse{RiskLevel: "medium"}, nil, paymentgateway.CostUsage{})
+	assert.ErrorIs(t, err, paymentgateway.ErrStepUpUnavailable)
+
+	// Sem registo do step-up o desafio emitido é cancelado
+	client = &fakeIdentityStepUpClient{}
+	service, _, _ = newStepUpService(t, failingStepUpStore{paymentgateway.NewInMemoryPaymentStepUpStore()}, client)
+	err = service.Begin(context.Background(), req, &paymentgateway.PaymentResponse{RiskLevel: "medium"}, nil, paymentgateway.CostUsage{})
+	require.Error(t, err)
+	assert.Equal(t, "payment_aborted", client.cancellation("chl-1"))
+}
+
+func TestStepUpCallbackOutcomes(t *testing.T) {
+	tests := []struct {
+		name           string
+		outcome        string
+		delay          time.Duration
+		expectedStatus string
+		paymentStatus  string
+		paymentCode    string
+	}{
+		{"desafio concluído retoma o pagamento", paymentgateway.StepUpOutcomeCompleted, time.Minute, paymentgateway.StepUpStatusVerified, paymentgateway.TransactionStatusApproved, paymentgateway.StepUpApprovedStatusCode},
+		{"desafio falhado aborta o pagamento", paymentgateway.StepUpOutcomeFailed, time.Minute, paymentgateway.StepUpStatusFailed, paymentgateway.TransactionStatusDenied, paymentgateway.StepUpFailedStatusCode},
+		{"desafio cancelado aborta o pagamento", paymentgateway.StepUpOutcomeCancelled, time.Minute, paymentgateway.StepUpStatusFailed, paymentgateway.TransactionStatusDenied, paymentgateway.StepUpFailedStatusCode},
+		{"desafio expirado aborta o pagamento", paymentgateway.StepUpOutcomeExpired, time.Minute, paymentgateway.StepUpStatusExpired, paymentgateway.TransactionStatusDenied, paymentgateway.StepUpExpiredStatusCode},
+		{"conclusão depois do prazo aborta o pagamento", paymentgateway.StepUpOutcomeCompleted, 6 * time.Minute, paymentgateway.StepUpStatusExpired, paymentgateway.TransactionStatusDenied, paymentgateway.StepUpExpiredStatusCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, _, clock := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), &fakeIdentityStepUpClient{})
+			beginStepUp(t, service, "tx-1")
+
+			clock.Advance(tt.delay)
+			payload, timestamp, signature := signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-1", tt.outcome))
+			stepUp, err := service.HandleCallback(ctx, payload, timestamp, signature)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stepUp.Status)
+			require.NotNil(t, stepUp.CompletedAt)
+			require.NotNil(t, stepUp.Response)
+			assert.Equal(t, tt.paymentStatus, stepUp.Response.Status)
+			assert.Equal(t, tt.paymentCode, stepUp.Response.StatusCode)
+			assert.False(t, stepUp.Response.ChallengeRequired)
+			if tt.paymentStatus == paymentgateway.TransactionStatusApproved {
+				assert.NotEmpty(t, stepUp.Response.ApprovalCode)
+				assert.Equal(t, "high", stepUp.MFALevel)
+			}
+
+			stored, err := service.GetStepUp(ctx, "tenant-1", "tx-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+			assert.Equal(t, tt.paymentCode, stored.Response.StatusCode)
+
+			// Notificações repetidas retornam o resultado gravado sem alterar o pagamento
+			payload, timestamp, signature = signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-1", paymentgateway.StepUpOutcomeFailed))
+			repeated, err := service.HandleCallback(ctx, payload, timestamp, signature)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, repeated.Status)
+			assert.Equal(t, tt.paymentCode, repeated.Response.StatusCode)
+		})
+	}
+}
+
+func TestStepUpCallbackRejected(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), &fakeIdentityStepUpClient{})
+	beginStepUp(t, service, "tx-1")
+
+	valid := stepUpCallback("chl-1", paymentgateway.StepUpOutcomeCompleted)
+	payload, timestamp, signature := signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), valid)
+
+	tests := []struct {
+		name      string
+		payload   func() ([]byte, string, string)
+		expectErr error
+	}{
+		{
+			name: "assinatura com outro segredo",
+			payload: func() ([]byte, string, string) {
+				return signedStepUpCallback(t, "outro-segredo", clock.Now(), valid)
+			},
+			expectErr: paymentgateway.ErrStepUpSignatureInvalid,
+		},
+		{
+			name:      "sem assinatura",
+			payload:   func() ([]byte, string, string) { return payload, timestamp, "" },
+			expectErr: paymentgateway.ErrStepUpSignatureInvalid,
+		},
+		{
+			name:      "carimbo temporal inválido",
+			payload:   func() ([]byte, string, string) { return payload, "ontem", signature },
+			expectErr: paymentgateway.ErrStepUpSignatureInvalid,
+		},
+		{
+			name: "corpo alterado depois de assinado",
+			payload: func() ([]byte, string, string) {
+				tampered, _, _ := signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-1", paymentgateway.StepUpOutcomeFailed))
+				return tampered, timestamp, signature
+			},
+			expectErr: paymentgateway.ErrStepUpSignatureInvalid,
+		},
+		{
+			name: "carimbo temporal fora do desvio permitido",
+			payload: func() ([]byte, string, string) {
+				return signedStepUpCallback(t, stepUpCallbackSecret, clock.Now().Add(-6*time.Minute), valid)
+			},
+			expectErr: paymentgateway.ErrStepUpSignatureInvalid,
+		},
+		{
+			name: "resultado desconhecido",
+			payload: func() ([]byte, string, string) {
+				return signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-1", "approved"))
+			},
+			expectErr: paymentgateway.ErrStepUpCallbackInvalid,
+		},
+		{
+			name: "desafio desconhecido",
+			payload: func() ([]byte, string, string) {
+				return signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-9", paymentgateway.StepUpOutcomeCompleted))
+			},
+			expectErr: paymentgateway.ErrStepUpNotFound,
+		},
+		{
+			name: "tenant de outro pagamento",
+			payload: func() ([]byte, string, string) {
+				callback := valid
+				callback.TenantID = "tenant-2"
+				return signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), callback)
+			},
+			expectErr: paymentgateway.ErrStepUpNotFound,
+		},
+		{
+			name: "usuário de outro pagamento",
+			payload: func() ([]byte, string, string) {
+				callback := valid
+				callback.UserID = "user-2"
+				return signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), callback)
+			},
+			expectErr: paymentgateway.ErrStepUpCallbackInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, ts, sig := tt.payload()
+			_, err := service.HandleCallback(ctx, body, ts, sig)
+			assert.ErrorIs(t, err, tt.expectErr)
+		})
+	}
+
+	// As notificações recusadas não resolvem o step-up
+	stepUp, err := service.GetStepUp(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.StepUpStatusPending, stepUp.Status)
+}
+
+func TestStepUpExpirePending(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeIdentityStepUpClient{}
+	service, _, clock := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), client)
+	beginStepUp(t, service, "tx-1")
+	clock.Advance(3 * time.Minute)
+	beginStepUp(t, service, "tx-2")
+
+	// Nenhum prazo terminou
+	aborted, err := service.ExpirePending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, aborted)
+
+	clock.Advance(3 * time.Minute)
+	aborted, err = service.ExpirePending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, aborted)
+
+	expired, err := service.GetStepUp(ctx, "tenant-1", "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.StepUpStatusExpired, expired.Status)
+	assert.Equal(t, paymentgateway.TransactionStatusDenied, expired.Response.Status)
+	assert.Equal(t, paymentgateway.StepUpExpiredStatusCode, expired.Response.StatusCode)
+	assert.Equal(t, "expired", client.cancellation("chl-1"))
+
+	pending, err := service.GetStepUp(ctx, "tenant-1", "tx-2")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.StepUpStatusPending, pending.Status)
+	assert.Empty(t, client.cancellation("chl-2"))
+
+	// Uma conclusão tardia do desafio abortado não retoma o pagamento
+	payload, timestamp, signature := signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback("chl-1", paymentgateway.StepUpOutcomeCompleted))
+	stepUp, err := service.HandleCallback(ctx, payload, timestamp, signature)
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.StepUpStatusExpired, stepUp.Status)
+}
+
+func TestStepUpProcessPayment(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeIdentityStepUpClient{}
+	service, connector, clock := newStepUpService(t, paymentgateway.NewInMemoryPaymentStepUpStore(), client)
+
+	orchestrator, err := cv.NewCrossVerificationOrchestrator(cv.OrchestrationConfig{MinRequiredScore: 60})
+	require.NoError(t, err)
+	orchestrator.RegisterVerifier(stepUpVerifier{score: 70})
+	connector.SetVerifier(orchestrator)
+
+	// O risco médio suspende o pagamento em vez de emitir o desafio simulado
+	response, err := connector.ProcessPayment(ctx, stepUpRequest("tx-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "medium", response.RiskLevel)
+	assert.Equal(t, paymentgateway.TransactionStatusChallenged, response.Status)
+	assert.Equal(t, paymentgateway.StepUpRequiredStatusCode, response.StatusCode)
+	assert.True(t, response.ChallengeRequired)
+	require.NotNil(t, response.ChallengeDetails)
+	assert.Equal(t, "mfa_step_up", response.ChallengeDetails.ChallengeType)
+	require.Len(t, client.requests, 1)
+
+	payload, timestamp, signature := signedStepUpCallback(t, stepUpCallbackSecret, clock.Now(), stepUpCallback(response.ChallengeDetails.ChallengeID, paymentgateway.StepUpOutcomeCompleted))
+	stepUp, err := service.HandleCallback(ctx, payload, timestamp, signature)
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.TransactionStatusApproved, stepUp.Response.Status)
+	assert.Equal(t, paymentgateway.StepUpApprovedStatusCode, stepUp.Response.StatusCode)
+	assert.Equal(t, "tx-1", stepUp.Response.TransactionID)
+
+	// Sem o serviço de identidade o pagamento termina em erro
+	client.err = paymentgateway.ErrStepUpUnavailable
+	response, err = connector.ProcessPayment(ctx, stepUpRequest("tx-2"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.TransactionStatusError, response.Status)
+	assert.Equal(t, "step_up_indisponivel", response.StatusCode)
+
+	// O risco baixo não exige step-up
+	lowRisk, err := cv.NewCrossVerificationOrchestrator(cv.OrchestrationConfig{MinRequiredScore: 60})
+	require.NoError(t, err)
+	lowRisk.RegisterVerifier(stepUpVerifier{score: 95})
+	connector.SetVerifier(lowRisk)
+	client.err = nil
+	response, err = connector.ProcessPayment(ctx, stepUpRequest("tx-3"))
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.TransactionStatusApproved, response.Status)
+	assert.Len(t, client.requests, 1)
+}
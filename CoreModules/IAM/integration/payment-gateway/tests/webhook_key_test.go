@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	paymentgateway "github.com/innovabizdevops/innovabiz-iam/integration/payment-gateway"
+)
+
+// webhookKeyMerchants conhece apenas o comerciante merchant-1 do tenant-1
+type webhookKeyMerchants struct {
+	paymentgateway.MerchantRepository
+}
+
+func (webhookKeyMerchants) GetMerchant(ctx context.Context, tenantID, merchantID string) (*paymentgateway.Merchant, error) {
+	if tenantID != "tenant-1" || merchantID != "merchant-1" {
+		return nil, paymentgateway.ErrMerchantNotFound
+	}
+	return &paymentgateway.Merchant{TenantID: tenantID, MerchantID: merchantID}, nil
+}
+
+func newWebhookKeyService(t *testing.T) (*paymentgateway.WebhookKeyService, *paymentgateway.InMemoryWebhookKeyStore, *testClock) {
+	t.Helper()
+
+	store := paymentgateway.NewInMemoryWebhookKeyStore()
+	service, err := paymentgateway.NewWebhookKeyService(paymentgateway.WebhookKeyConfig{
+		MasterKey:      bytes.Repeat([]byte{7}, 32),
+		DefaultOverlap: time.Hour,
+		MaxOverlap:     48 * time.Hour,
+	}, store, webhookKeyMerchants{})
+	require.NoError(t, err)
+	clock := newTestClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	service.SetClock(clock.Now)
+	return service, store, clock
+}
+
+func rotateWebhookKey(t *testing.T, service *paymentgateway.WebhookKeyService, clock *testClock, req paymentgateway.WebhookKeyRotationRequest) *paymentgateway.WebhookKeyRotationResult {
+	t.Helper()
+	// As chaves são ordenadas pela data de criação
+	clock.Advance(time.Second)
+	req.TenantID, req.MerchantID = "tenant-1", "merchant-1"
+	result, err := service.RotateKey(context.Background(), &req)
+	require.NoError(t, err)
+	return result
+}
+
+// webhookSignatures separa as assinaturas "<versão>,<base64>" do cabeçalho
+func webhookSignatures(t *testing.T, headers http.Header) [][2]string {
+	t.Helper()
+	var signatures [][2]string
+	for _, entry := range strings.Fields(headers.Get(paymentgateway.WebhookSignatureHeader)) {
+		parts := strings.SplitN(entry, ",", 2)
+		require.Len(t, parts, 2)
+		signatures = append(signatures, [2]string{parts[0], parts[1]})
+	}
+	return signatures
+}
+
+// webhookContent monta o conteúdo assinado como o comerciante o reconstrói
+func webhookContent(headers http.Header, payload []byte) []byte {
+	return []byte(headers.Get(paymentgateway.WebhookIDHeader) + "." + headers.Get(paymentgateway.WebhookTimestampHeader) + "." + string(payload))
+}
+
+func verifyHMACSignature(t *testing.T, secret, signature string, content []byte) bool {
+	t.Helper()
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)) == signature
+}
+
+func TestWebhookKeyRotationOverlapWindow(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newWebhookKeyService(t)
+	payload := []byte(`{"event_type":"payment.completed"}`)
+
+	first := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{})
+	assert.Equal(t, paymentgateway.WebhookKeyAlgorithmHMACSHA256, first.Key.Algorithm)
+	assert.True(t, strings.HasPrefix(first.Secret, "whsec_"))
+	assert.Empty(t, first.Retiring)
+
+	headers, err := service.SignWebhook(ctx, "tenant-1", "merchant-1", "whm-1", payload)
+	require.NoError(t, err)
+	assert.Equal(t, "whm-1", headers.Get(paymentgateway.WebhookIDHeader))
+	signatures := webhookSignatures(t, headers)
+	require.Len(t, signatures, 1)
+	assert.Equal(t, paymentgateway.WebhookSignatureVersionHMAC, signatures[0][0])
+	assert.True(t, verifyHMACSignature(t, first.Secret, signatures[0][1], webhookContent(headers, payload)))
+
+	// Durante a janela de sobreposição os webhooks levam as assinaturas das duas chaves
+	second := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{Overlap: 2 * time.Hour})
+	require.Len(t, second.Retiring, 1)
+	assert.Equal(t, first.Key.KeyID, second.Retiring[0].KeyID)
+	assert.Equal(t, paymentgateway.WebhookKeyStatusRetiring, second.Retiring[0].Status)
+	assert.Equal(t, clock.Now().Add(2*time.Hour), *second.Retiring[0].RetiresAt)
+
+	headers, err = service.SignWebhook(ctx, "tenant-1", "merchant-1", "whm-2", payload)
+	require.NoError(t, err)
+	signatures = webhookSignatures(t, headers)
+	require.Len(t, signatures, 2)
+	content := webhookContent(headers, payload)
+	assert.True(t, verifyHMACSignature(t, second.Secret, signatures[0][1], content), "a chave ativa assina primeiro")
+	assert.True(t, verifyHMACSignature(t, first.Secret, signatures[1][1], content))
+
+	keys, err := service.VerificationKeys(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	secret, err := service.GetKeySecret(ctx, "tenant-1", "merchant-1", first.Key.KeyID, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.Secret, secret.Secret)
+
+	// No fim da janela a chave anterior deixa de assinar e o segredo deixa de ser visível
+	clock.Advance(2 * time.Hour)
+	headers, err = service.SignWebhook(ctx, "tenant-1", "merchant-1", "whm-3", payload)
+	require.NoError(t, err)
+	signatures = webhookSignatures(t, headers)
+	require.Len(t, signatures, 1)
+	assert.True(t, verifyHMACSignature(t, second.Secret, signatures[0][1], webhookContent(headers, payload)))
+
+	keys, err = service.VerificationKeys(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, second.Key.KeyID, keys[0].KeyID)
+
+	listed, err := service.ListKeys(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, paymentgateway.WebhookKeyStatusRetired, listed[1].Status)
+
+	_, err = service.GetKeySecret(ctx, "tenant-1", "merchant-1", first.Key.KeyID, "admin-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyStateInvalid)
+	_, err = service.RevokeKey(ctx, "tenant-1", "merchant-1", first.Key.KeyID, "comprometida", "admin-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyStateInvalid)
+}
+
+func TestWebhookKeyImmediateRotationAndRevocation(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newWebhookKeyService(t)
+	payload := []byte(`{}`)
+
+	first := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{})
+	second := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{})
+
+	// A rotação imediata revoga todas as chaves que ainda assinam
+	third := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{Immediate: true, Reason: "comprometimento"})
+	assert.Empty(t, third.Retiring)
+	listed, err := service.ListKeys(ctx, "tenant-1", "merchant-1")
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+	for _, key := range listed[1:] {
+		assert.Equal(t, paymentgateway.WebhookKeyStatusRevoked, key.Status)
+		assert.Equal(t, "comprometimento", key.RevokedReason)
+	}
+	assert.ElementsMatch(t, []string{first.Key.KeyID, second.Key.KeyID}, []string{listed[1].KeyID, listed[2].KeyID})
+
+	headers, err := service.SignWebhook(ctx, "tenant-1", "merchant-1", "", payload)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(headers.Get(paymentgateway.WebhookIDHeader), "whm-"))
+	signatures := webhookSignatures(t, headers)
+	require.Len(t, signatures, 1)
+	assert.True(t, verifyHMACSignature(t, third.Secret, signatures[0][1], webhookContent(headers, payload)))
+
+	// Revogar a chave ativa deixa o comerciante sem assinatura até à próxima rotação
+	revoked, err := service.RevokeKey(ctx, "tenant-1", "merchant-1", third.Key.KeyID, "pedido do comerciante", "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, paymentgateway.WebhookKeyStatusRevoked, revoked.Status)
+	_, err = service.SignWebhook(ctx, "tenant-1", "merchant-1", "", payload)
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyNoSigningKey)
+
+	_, err = service.RevokeKey(ctx, "tenant-1", "merchant-1", "whk-unknown", "", "admin-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyNotFound)
+	_, err = service.GetKeySecret(ctx, "tenant-2", "merchant-1", third.Key.KeyID, "admin-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyNotFound)
+}
+
+func TestWebhookKeyEd25519Signatures(t *testing.T) {
+	ctx := context.Background()
+	service, _, clock := newWebhookKeyService(t)
+	payload := []byte(`{"event_type":"refund.completed"}`)
+
+	ed := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{Algorithm: paymentgateway.WebhookKeyAlgorithmEd25519})
+	assert.Empty(t, ed.Secret)
+	require.True(t, strings.HasPrefix(ed.Key.PublicKey, "whpk_"))
+	publicKey, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ed.Key.PublicKey, "whpk_"))
+	require.NoError(t, err)
+
+	_, err = service.GetKeySecret(ctx, "tenant-1", "merchant-1", ed.Key.KeyID, "admin-1")
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeySecretNotVisible)
+
+	// Mudança de algoritmo com sobreposição: uma assinatura de cada versão
+	hmacKey := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{Algorithm: paymentgateway.WebhookKeyAlgorithmHMACSHA256})
+	headers, err := service.SignWebhook(ctx, "tenant-1", "merchant-1", "whm-1", payload)
+	require.NoError(t, err)
+	signatures := webhookSignatures(t, headers)
+	require.Len(t, signatures, 2)
+	content := webhookContent(headers, payload)
+
+	assert.Equal(t, paymentgateway.WebhookSignatureVersionHMAC, signatures[0][0])
+	assert.True(t, verifyHMACSignature(t, hmacKey.Secret, signatures[0][1], content))
+	assert.Equal(t, paymentgateway.WebhookSignatureVersionEd25519, signatures[1][0])
+	signature, err := base64.StdEncoding.DecodeString(signatures[1][1])
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(ed25519.PublicKey(publicKey), content, signature))
+	assert.False(t, ed25519.Verify(ed25519.PublicKey(publicKey), append(content, ' '), signature))
+}
+
+func TestWebhookKeyMaterialBoundToKey(t *testing.T) {
+	ctx := context.Background()
+	service, store, clock := newWebhookKeyService(t)
+
+	first := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{})
+	second := rotateWebhookKey(t, service, clock, paymentgateway.WebhookKeyRotationRequest{})
+
+	// O segredo nunca é guardado em claro
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(second.Secret, "whsec_"))
+	require.NoError(t, err)
+	stored, err := store.GetKey(ctx, "tenant-1", "merchant-1", second.Key.KeyID)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stored.SecretCiphertext, raw))
+
+	// O texto cifrado de uma chave não decifra com o identificador de outra
+	previous, err := store.GetKey(ctx, "tenant-1", "merchant-1", first.Key.KeyID)
+	require.NoError(t, err)
+	previous.SecretCiphertext = stored.SecretCiphertext
+	require.NoError(t, store.UpdateKey(ctx, previous))
+	_, err = service.GetKeySecret(ctx, "tenant-1", "merchant-1", first.Key.KeyID, "admin-1")
+	assert.Error(t, err)
+	_, err = service.SignWebhook(ctx, "tenant-1", "merchant-1", "whm-1", []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestWebhookKeyValidation(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newWebhookKeyService(t)
+
+	tests := []struct {
+		name string
+		req  paymentgateway.WebhookKeyRotationRequest
+		err  error
+	}{
+		{"sem comerciante", paymentgateway.WebhookKeyRotationRequest{TenantID: "tenant-1"}, paymentgateway.ErrWebhookKeyInvalid},
+		{"algoritmo desconhecido", paymentgateway.WebhookKeyRotationRequest{TenantID: "tenant-1", MerchantID: "merchant-1", Algorithm: "rsa"}, paymentgateway.ErrWebhookKeyInvalid},
+		{"sobreposição acima da máxima", paymentgateway.WebhookKeyRotationRequest{TenantID: "tenant-1", MerchantID: "merchant-1", Overlap: 72 * time.Hour}, paymentgateway.ErrWebhookKeyInvalid},
+		{"sobreposição negativa", paymentgateway.WebhookKeyRotationRequest{TenantID: "tenant-1", MerchantID: "merchant-1", Overlap: -time.Hour}, paymentgateway.ErrWebhookKeyInvalid},
+		{"comerciante de outro tenant", paymentgateway.WebhookKeyRotationRequest{TenantID: "tenant-2", MerchantID: "merchant-1"}, paymentgateway.ErrMerchantNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			_, err := service.RotateKey(ctx, &req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	_, err := service.SignWebhook(ctx, "tenant-1", "merchant-1", "", []byte(`{}`))
+	assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyNoSigningKey)
+
+	for _, config := range []paymentgateway.WebhookKeyConfig{
+		{MasterKey: []byte("curta")},
+		{MasterKey: bytes.Repeat([]byte{7}, 32), DefaultAlgorithm: "rsa"},
+		{MasterKey: bytes.Repeat([]byte{7}, 32), DefaultOverlap: 72 * time.Hour, MaxOverlap: 48 * time.Hour},
+	} {
+		_, err := paymentgateway.NewWebhookKeyService(config, paymentgateway.NewInMemoryWebhookKeyStore(), webhookKeyMerchants{})
+		assert.ErrorIs(t, err, paymentgateway.ErrWebhookKeyConfigInvalid)
+	}
+}
@@ -0,0 +1,88 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresTransactionRecordStore implementa TransactionRecordStore para PostgreSQL
+type PostgresTransactionRecordStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresTransactionRecordStore cria uma nova instância de PostgresTransactionRecordStore
+func NewPostgresTransactionRecordStore(db *sqlx.DB) *PostgresTransactionRecordStore {
+	return &PostgresTransactionRecordStore{db: db}
+}
+
+// dbTransactionRecord é a representação de TransactionRecord na base de dados
+type dbTransactionRecord struct {
+	TransactionRecord
+	RiskExplanationJSON []byte `db:"risk_explanation"`
+}
+
+// SaveTransactionRecord grava o registo da transação, substituindo o anterior
+func (r *PostgresTransactionRecordStore) SaveTransactionRecord(ctx context.Context, record *TransactionRecord) error {
+	row := &dbTransactionRecord{TransactionRecord: *record}
+
+	var err error
+	if row.RiskExplanationJSON, err = json.Marshal(record.RiskExplanation); err != nil {
+		return fmt.Errorf("falha ao codificar explicação de risco: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.transaction_records (
+			tenant_id, transaction_id, merchant_id, user_id, device_fingerprint, payment_method, amount, currency,
+			risk_score, risk_explanation, recorded_at
+		) VALUES (
+			:tenant_id, :transaction_id, :merchant_id, :user_id, :device_fingerprint, :payment_method, :amount, :currency,
+			:risk_score, :risk_explanation, :recorded_at
+		)
+		ON CONFLICT (tenant_id, transaction_id) DO UPDATE SET
+			merchant_id = EXCLUDED.merchant_id,
+			user_id = EXCLUDED.user_id,
+			device_fingerprint = EXCLUDED.device_fingerprint,
+			payment_method = EXCLUDED.payment_method,
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			risk_score = EXCLUDED.risk_score,
+			risk_explanation = EXCLUDED.risk_explanation,
+			recorded_at = EXCLUDED.recorded_at
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar registo da transação: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactionRecord recupera o registo da transação do tenant
+func (r *PostgresTransactionRecordStore) GetTransactionRecord(ctx context.Context, tenantID, transactionID string) (*TransactionRecord, error) {
+	var row dbTransactionRecord
+	query := `
+		SELECT tenant_id, transaction_id, merchant_id, user_id, device_fingerprint, payment_method, amount, currency,
+			risk_score, risk_explanation, recorded_at
+		FROM payment_gateway.transaction_records
+		WHERE tenant_id = $1 AND transaction_id = $2
+	`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionRecordNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar registo da transação: %w", err)
+	}
+
+	record := row.TransactionRecord
+	if len(row.RiskExplanationJSON) > 0 {
+		if err := json.Unmarshal(row.RiskExplanationJSON, &record.RiskExplanation); err != nil {
+			return nil, fmt.Errorf("falha ao decodificar explicação de risco: %w", err)
+		}
+	}
+	return &record, nil
+}
@@ -0,0 +1,62 @@
+package paymentgateway
+
+import (
+	"context"
+	"sync"
+)
+
+// TransactionRecordStore define a persistência dos registos das transações avaliadas pelo motor de risco
+type TransactionRecordStore interface {
+	// SaveTransactionRecord grava o registo da transação, substituindo o anterior
+	SaveTransactionRecord(ctx context.Context, record *TransactionRecord) error
+
+	// GetTransactionRecord recupera o registo da transação do tenant; retorna ErrTransactionRecordNotFound quando não existe
+	GetTransactionRecord(ctx context.Context, tenantID, transactionID string) (*TransactionRecord, error)
+}
+
+// InMemoryTransactionRecordStore armazena os registos das transações em memória
+type InMemoryTransactionRecordStore struct {
+	records map[string]*TransactionRecord
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryTransactionRecordStore cria um novo armazenamento em memória
+func NewInMemoryTransactionRecordStore() *InMemoryTransactionRecordStore {
+	return &InMemoryTransactionRecordStore{records: make(map[string]*TransactionRecord)}
+}
+
+// SaveTransactionRecord grava uma cópia do registo
+func (s *InMemoryTransactionRecordStore) SaveTransactionRecord(ctx context.Context, record *TransactionRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[record.TenantID+"/"+record.TransactionID] = copyTransactionRecord(record)
+	return nil
+}
+
+// GetTransactionRecord retorna uma cópia do registo da transação do tenant
+func (s *InMemoryTransactionRecordStore) GetTransactionRecord(ctx context.Context, tenantID, transactionID string) (*TransactionRecord, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, ok := s.records[tenantID+"/"+transactionID]
+	if !ok {
+		return nil, ErrTransactionRecordNotFound
+	}
+	return copyTransactionRecord(record), nil
+}
+
+// copyTransactionRecord copia o registo e a explicação de risco
+func copyTransactionRecord(record *TransactionRecord) *TransactionRecord {
+	copied := *record
+	if record.RiskExplanation != nil {
+		explanation := *record.RiskExplanation
+		explanation.Rules = make([]RiskRuleExplanation, len(record.RiskExplanation.Rules))
+		for i, rule := range record.RiskExplanation.Rules {
+			rule.MatchedConditions = append([]string(nil), rule.MatchedConditions...)
+			explanation.Rules[i] = rule
+		}
+		copied.RiskExplanation = &explanation
+	}
+	return &copied
+}
@@ -0,0 +1,71 @@
+package paymentgateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookDeliveryHandler expõe às equipas de suporte as entregas de webhook e a reentrega manual
+// As rotas devem ser registadas num subrouter protegido por SupportAuth.Middleware
+type WebhookDeliveryHandler struct {
+	service *WebhookDeliveryService
+}
+
+// NewWebhookDeliveryHandler cria uma nova instância do WebhookDeliveryHandler
+func NewWebhookDeliveryHandler(service *WebhookDeliveryService) *WebhookDeliveryHandler {
+	return &WebhookDeliveryHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *WebhookDeliveryHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/support/webhooks/deliveries", h.ListDeliveries).Methods(http.MethodGet)
+	router.HandleFunc("/support/webhooks/deliveries/{deliveryId}/redeliver", h.Redeliver).Methods(http.MethodPost)
+}
+
+// ListDeliveries lista as entregas do tenant, filtradas por merchant_id e status
+func (h *WebhookDeliveryHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter := WebhookDeliveryFilter{
+		TenantID:   r.Header.Get("X-Tenant-ID"),
+		MerchantID: r.URL.Query().Get("merchant_id"),
+		Status:     r.URL.Query().Get("status"),
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao listar entregas de webhook")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// Redeliver reagenda uma entrega com um novo ciclo de tentativas
+func (h *WebhookDeliveryHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get("X-Tenant-ID")
+	deliveryID := mux.Vars(r)["deliveryId"]
+
+	delivery, err := h.service.Redeliver(r.Context(), tenantID, deliveryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrWebhookDeliveryNotFound):
+			respondWithError(w, http.StatusNotFound, "not_found", "Entrega de webhook não encontrada")
+		case errors.Is(err, ErrWebhookDeliveryInFlight):
+			respondWithError(w, http.StatusConflict, "delivery_in_flight", "Entrega de webhook em curso")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao reagendar entrega de webhook")
+		}
+		return
+	}
+
+	// Registar a reentrega para auditoria das ações das equipas de suporte
+	h.service.logger.InfoWithContext(r.Context(), "Entrega de webhook reagendada pelo suporte",
+		"tenant_id", tenantID,
+		"delivery_id", deliveryID,
+		"merchant_id", delivery.MerchantID,
+		"transaction_id", delivery.Event.TransactionID,
+		"operator", supportOperator(r))
+
+	respondWithJSON(w, http.StatusAccepted, delivery)
+}
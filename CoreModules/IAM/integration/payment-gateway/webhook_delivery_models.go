@@ -0,0 +1,158 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Eventos do ciclo de vida das transações notificados aos comerciantes
+const (
+	WebhookEventTransactionProcessing = "transaction.processing"
+	WebhookEventTransactionCompleted  = "transaction.completed"
+	WebhookEventTransactionFailed     = "transaction.failed"
+)
+
+// Status das entregas de webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Tentativas esgotadas; requer reentrega manual
+)
+
+// WebhookEventHeader transporta o tipo do evento; o identificador da entrega segue em X-Webhook-Id
+const WebhookEventHeader = "X-Webhook-Event"
+
+// Valores padrão do envio das notificações
+const (
+	DefaultWebhookSchedulerInterval = time.Second
+	DefaultWebhookMaxConcurrency    = 8
+	DefaultWebhookRequestTimeout    = 10 * time.Second
+	DefaultWebhookRetention         = 7 * 24 * time.Hour // Entregas concluídas são descartadas após este período
+	webhookPruneInterval            = time.Hour
+)
+
+// Erros das entregas de webhook
+var (
+	ErrWebhookDeliveryNotFound = errors.New("entrega de webhook não encontrada")
+	ErrWebhookDeliveryInFlight = errors.New("entrega de webhook em curso")
+)
+
+// WebhookRetryPolicy define as novas tentativas de entrega para um comerciante
+type WebhookRetryPolicy struct {
+	MaxAttempts    int           `json:"max_attempts"`    // Tentativas por entrega, incluindo a primeira
+	InitialBackoff time.Duration `json:"initial_backoff"` // Espera antes da segunda tentativa
+	MaxBackoff     time.Duration `json:"max_backoff"`     // Espera máxima entre tentativas
+	Multiplier     float64       `json:"multiplier"`      // Fator de crescimento da espera
+
+	// Circuit breaker do endpoint: após FailureThreshold falhas consecutivas as entregas
+	// ficam suspensas durante CircuitCooldown, sem consumir tentativas
+	FailureThreshold int           `json:"failure_threshold"`
+	CircuitCooldown  time.Duration `json:"circuit_cooldown"`
+}
+
+// DefaultWebhookRetryPolicy é aplicada aos comerciantes sem política própria
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{
+	MaxAttempts:      8,
+	InitialBackoff:   30 * time.Second,
+	MaxBackoff:       time.Hour,
+	Multiplier:       2,
+	FailureThreshold: 10,
+	CircuitCooldown:  15 * time.Minute,
+}
+
+// backoff calcula a espera após a tentativa indicada (1 = primeira tentativa)
+func (p WebhookRetryPolicy) backoff(attempt int) time.Duration {
+	wait := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		wait *= p.Multiplier
+		if wait >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(wait)
+}
+
+// withDefaults completa os campos não configurados com os valores padrão
+func (p WebhookRetryPolicy) withDefaults() WebhookRetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultWebhookRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultWebhookRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultWebhookRetryPolicy.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultWebhookRetryPolicy.Multiplier
+	}
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = DefaultWebhookRetryPolicy.FailureThreshold
+	}
+	if p.CircuitCooldown <= 0 {
+		p.CircuitCooldown = DefaultWebhookRetryPolicy.CircuitCooldown
+	}
+	return p
+}
+
+// WebhookDeliveryConfig contém as configurações do envio das notificações aos comerciantes
+type WebhookDeliveryConfig struct {
+	// URL de notificação por comerciante; comerciantes sem URL não são notificados
+	Endpoints map[string]string `json:"endpoints"`
+
+	// Política de novas tentativas por comerciante
+	RetryPolicies map[string]WebhookRetryPolicy `json:"retry_policies"`
+
+	SchedulerInterval time.Duration `json:"scheduler_interval"`
+	MaxConcurrency    int           `json:"max_concurrency"`
+	RequestTimeout    time.Duration `json:"request_timeout"`
+	Retention         time.Duration `json:"retention"`
+}
+
+// WebhookEvent é o corpo JSON enviado ao comerciante
+type WebhookEvent struct {
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	TenantID      string    `json:"tenant_id"`
+	MerchantID    string    `json:"merchant_id"`
+	TransactionID string    `json:"transaction_id"`
+	Status        string    `json:"status"`
+	PaymentMethod string    `json:"payment_method"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	ProcessorRef  string    `json:"processor_ref,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Market        string    `json:"market"`
+	Sandbox       bool      `json:"sandbox,omitempty"` // Pagamento de um comerciante em modo sandbox
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// WebhookDelivery acompanha a entrega de um evento ao endpoint do comerciante
+type WebhookDelivery struct {
+	DeliveryID     string       `json:"delivery_id" db:"delivery_id"`
+	TenantID       string       `json:"tenant_id" db:"tenant_id"`
+	MerchantID     string       `json:"merchant_id" db:"merchant_id"`
+	Event          WebhookEvent `json:"event" db:"-"`
+	URL            string       `json:"url" db:"url"`
+	Status         string       `json:"status" db:"status"`
+	Attempts       int          `json:"attempts" db:"attempts"`
+	LastStatusCode int          `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string       `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time    `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time   `json:"delivered_at,omitempty" db:"delivered_at"`
+	Redeliveries   int          `json:"redeliveries" db:"redeliveries"`
+}
+
+// WebhookDeliveryFilter filtra a listagem das entregas de um tenant
+type WebhookDeliveryFilter struct {
+	TenantID   string
+	MerchantID string
+	Status     string
+}
+
+// webhookCircuit é o estado do circuit breaker de um endpoint de comerciante
+type webhookCircuit struct {
+	consecutiveFailures int
+	openUntil           time.Time
+}
@@ -0,0 +1,152 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresWebhookDeliveryStore implementa WebhookDeliveryStore para PostgreSQL
+type PostgresWebhookDeliveryStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresWebhookDeliveryStore cria uma nova instância de PostgresWebhookDeliveryStore
+func NewPostgresWebhookDeliveryStore(db *sqlx.DB) *PostgresWebhookDeliveryStore {
+	return &PostgresWebhookDeliveryStore{db: db}
+}
+
+// dbWebhookDelivery é a representação de WebhookDelivery na base de dados
+type dbWebhookDelivery struct {
+	WebhookDelivery
+	EventJSON []byte `db:"event"`
+}
+
+const webhookDeliveryColumns = `delivery_id, tenant_id, merchant_id, event, url, status, attempts,
+	last_status_code, last_error, next_attempt_at, created_at, delivered_at, redeliveries`
+
+// SaveDelivery grava a entrega, substituindo o estado anterior
+func (r *PostgresWebhookDeliveryStore) SaveDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	row := &dbWebhookDelivery{WebhookDelivery: *delivery}
+
+	var err error
+	if row.EventJSON, err = json.Marshal(delivery.Event); err != nil {
+		return fmt.Errorf("falha ao codificar evento do webhook: %w", err)
+	}
+
+	query := `
+		INSERT INTO payment_gateway.webhook_deliveries (` + webhookDeliveryColumns + `)
+		VALUES (
+			:delivery_id, :tenant_id, :merchant_id, :event, :url, :status, :attempts,
+			:last_status_code, :last_error, :next_attempt_at, :created_at, :delivered_at, :redeliveries
+		)
+		ON CONFLICT (delivery_id) DO UPDATE SET
+			url = EXCLUDED.url,
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			last_status_code = EXCLUDED.last_status_code,
+			last_error = EXCLUDED.last_error,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			delivered_at = EXCLUDED.delivered_at,
+			redeliveries = EXCLUDED.redeliveries
+	`
+
+	if _, err := r.db.NamedExecContext(ctx, query, row); err != nil {
+		return fmt.Errorf("falha ao gravar entrega de webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDelivery recupera a entrega do tenant
+func (r *PostgresWebhookDeliveryStore) GetDelivery(ctx context.Context, tenantID, deliveryID string) (*WebhookDelivery, error) {
+	var row dbWebhookDelivery
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM payment_gateway.webhook_deliveries
+		WHERE tenant_id = $1 AND delivery_id = $2`
+	if err := r.db.GetContext(ctx, &row, query, tenantID, deliveryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("falha ao recuperar entrega de webhook: %w", err)
+	}
+
+	return row.toDelivery()
+}
+
+// ListDeliveries lista as entregas filtradas, das mais recentes às mais antigas
+func (r *PostgresWebhookDeliveryStore) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{filter.TenantID}
+	if filter.MerchantID != "" {
+		args = append(args, filter.MerchantID)
+		conditions = append(conditions, fmt.Sprintf("merchant_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM payment_gateway.webhook_deliveries
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC`
+
+	return r.selectDeliveries(ctx, query, args...)
+}
+
+// ListDueDeliveries lista as entregas pendentes vencidas, das mais antigas às mais recentes
+func (r *PostgresWebhookDeliveryStore) ListDueDeliveries(ctx context.Context, until time.Time) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM payment_gateway.webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at`
+
+	return r.selectDeliveries(ctx, query, WebhookDeliveryPending, until)
+}
+
+// DeleteCompletedDeliveries remove as entregas entregues ou falhadas criadas antes do instante indicado
+func (r *PostgresWebhookDeliveryStore) DeleteCompletedDeliveries(ctx context.Context, before time.Time) (int, error) {
+	query := `DELETE FROM payment_gateway.webhook_deliveries WHERE status <> $1 AND created_at < $2`
+
+	result, err := r.db.ExecContext(ctx, query, WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao remover entregas de webhook: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("falha ao remover entregas de webhook: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// selectDeliveries executa a consulta e decodifica os eventos das entregas
+func (r *PostgresWebhookDeliveryStore) selectDeliveries(ctx context.Context, query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows := make([]dbWebhookDelivery, 0)
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("falha ao listar entregas de webhook: %w", err)
+	}
+
+	deliveries := make([]*WebhookDelivery, 0, len(rows))
+	for i := range rows {
+		delivery, err := rows[i].toDelivery()
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// toDelivery converte a linha na entrega com o evento decodificado
+func (row *dbWebhookDelivery) toDelivery() (*WebhookDelivery, error) {
+	delivery := row.WebhookDelivery
+	if err := json.Unmarshal(row.EventJSON, &delivery.Event); err != nil {
+		return nil, fmt.Errorf("falha ao decodificar evento do webhook: %w", err)
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,467 @@
+package paymentgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// WebhookSigner assina o corpo dos webhooks de um comerciante e devolve os cabeçalhos a enviar
+// É implementado por WebhookKeyService
+type WebhookSigner interface {
+	SignWebhook(ctx context.Context, tenantID, merchantID, webhookID string, payload []byte) (http.Header, error)
+}
+
+// WebhookDeliveryService entrega os eventos das transações aos endpoints dos comerciantes, com novas
+// tentativas em backoff exponencial e um circuit breaker por endpoint. As entregas são gravadas a
+// cada alteração e retomadas após um reinício
+type WebhookDeliveryService struct {
+	config WebhookDeliveryConfig
+	store  WebhookDeliveryStore
+	signer WebhookSigner
+	client *http.Client
+
+	mutex     sync.Mutex
+	inFlight  map[string]bool
+	circuits  map[string]*webhookCircuit
+	lastPrune time.Time
+	wake      chan struct{}
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+// NewWebhookDeliveryService cria o serviço de entrega dos webhooks
+// signer pode ser nil; nesse caso, e para comerciantes sem chave ativa, os webhooks seguem sem assinatura
+func NewWebhookDeliveryService(config WebhookDeliveryConfig, store WebhookDeliveryStore, signer WebhookSigner) (*WebhookDeliveryService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-webhooks",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	// Configurar valores padrão
+	if config.SchedulerInterval <= 0 {
+		config.SchedulerInterval = DefaultWebhookSchedulerInterval
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = DefaultWebhookMaxConcurrency
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = DefaultWebhookRequestTimeout
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultWebhookRetention
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookDeliveryService{
+		config:          config,
+		store:           store,
+		signer:          signer,
+		client:          &http.Client{Timeout: config.RequestTimeout},
+		inFlight:        make(map[string]bool),
+		circuits:        make(map[string]*webhookCircuit),
+		wake:            make(chan struct{}, 1),
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start inicia o agendador das entregas; as entregas pendentes gravadas antes de um reinício
+// são retomadas na primeira execução
+func (s *WebhookDeliveryService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.SchedulerInterval)
+		defer ticker.Stop()
+		for {
+			s.dispatchDue(s.ctx)
+			s.prune(s.ctx)
+
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.logger.Info("Agendador de webhooks iniciado", "max_concurrency", s.config.MaxConcurrency)
+}
+
+// Stop interrompe o agendador após as tentativas em curso
+func (s *WebhookDeliveryService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// NotifyTransaction agenda a notificação do resultado de um pagamento ao comerciante
+// Falhas ao agendar são registadas e não alteram o resultado do pagamento
+func (s *WebhookDeliveryService) NotifyTransaction(ctx context.Context, req *PaymentRequest, resp *PaymentResponse) {
+	event := WebhookEvent{
+		EventType:     WebhookEventTransactionProcessing,
+		TenantID:      req.TenantID,
+		MerchantID:    req.MerchantID,
+		TransactionID: resp.TransactionID,
+		Status:        resp.Status,
+		PaymentMethod: req.PaymentMethod,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ProcessorRef:  resp.AuthorizationID,
+		Market:        req.RegionCode,
+		Sandbox:       req.Sandbox,
+	}
+	switch resp.Status {
+	case TransactionStatusApproved:
+		event.EventType = WebhookEventTransactionCompleted
+	case TransactionStatusDenied, TransactionStatusError:
+		event.EventType = WebhookEventTransactionFailed
+		event.Reason = resp.StatusDescription
+	}
+
+	delivery, err := s.Enqueue(ctx, event)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao agendar notificação de transação",
+			"transaction_id", resp.TransactionID,
+			"merchant_id", req.MerchantID,
+			"error", err.Error())
+		return
+	}
+	if delivery != nil {
+		s.logger.DebugWithContext(ctx, "Notificação de transação agendada",
+			"delivery_id", delivery.DeliveryID,
+			"transaction_id", resp.TransactionID,
+			"event_type", event.EventType)
+	}
+}
+
+// Enqueue agenda a entrega do evento; para comerciantes sem URL de notificação retorna nil sem erro
+func (s *WebhookDeliveryService) Enqueue(ctx context.Context, event WebhookEvent) (*WebhookDelivery, error) {
+	url := s.config.Endpoints[event.MerchantID]
+	if url == "" {
+		return nil, nil
+	}
+
+	now := s.now()
+	if event.EventID == "" {
+		event.EventID = fmt.Sprintf("evt-%s", uuid.New().String())
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = now
+	}
+
+	delivery := &WebhookDelivery{
+		DeliveryID:    fmt.Sprintf("whd-%s", uuid.New().String()),
+		TenantID:      event.TenantID,
+		MerchantID:    event.MerchantID,
+		Event:         event,
+		URL:           url,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := s.store.SaveDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("falha ao gravar entrega de webhook: %w", err)
+	}
+
+	s.signal()
+	return delivery, nil
+}
+
+// Redeliver reagenda uma entrega com um novo ciclo de tentativas
+func (s *WebhookDeliveryService) Redeliver(ctx context.Context, tenantID, deliveryID string) (*WebhookDelivery, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.inFlight[deliveryID] {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookDeliveryInFlight, deliveryID)
+	}
+	delivery, err := s.store.GetDelivery(ctx, tenantID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A URL atual do comerciante substitui a registada na entrega original
+	if url := s.config.Endpoints[delivery.MerchantID]; url != "" {
+		delivery.URL = url
+	}
+	delivery.Status = WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = s.now()
+	delivery.Redeliveries++
+	if err := s.store.SaveDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("falha ao gravar entrega de webhook: %w", err)
+	}
+
+	// A reentrega manual testa o endpoint de imediato (meio-aberto): uma nova falha reabre o circuito
+	if circuit := s.circuits[webhookCircuitKey(delivery)]; circuit != nil {
+		circuit.openUntil = time.Time{}
+	}
+
+	s.signal()
+	return delivery, nil
+}
+
+// ListDeliveries lista as entregas do tenant filtradas por comerciante e status
+func (s *WebhookDeliveryService) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error) {
+	return s.store.ListDeliveries(ctx, filter)
+}
+
+// signal acorda o agendador sem bloquear
+func (s *WebhookDeliveryService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchDue tenta as entregas vencidas, até MaxConcurrency em paralelo, e aguarda o resultado
+func (s *WebhookDeliveryService) dispatchDue(ctx context.Context) {
+	due := s.claimDue(ctx)
+	if len(due) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, s.config.MaxConcurrency)
+	var workers sync.WaitGroup
+	for _, delivery := range due {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			s.release(delivery.DeliveryID)
+			continue
+		}
+		workers.Add(1)
+		go func(d *WebhookDelivery) {
+			defer workers.Done()
+			defer func() { <-sem }()
+			s.attempt(ctx, d)
+		}(delivery)
+	}
+	workers.Wait()
+}
+
+// claimDue marca como em curso as entregas pendentes cujo horário chegou
+// Entregas de endpoints com o circuito aberto são adiadas sem consumir tentativas
+func (s *WebhookDeliveryService) claimDue(ctx context.Context) []*WebhookDelivery {
+	now := s.now()
+	pending, err := s.store.ListDueDeliveries(ctx, now)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao listar entregas de webhook pendentes", "error", err.Error())
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	due := make([]*WebhookDelivery, 0, len(pending))
+	for _, delivery := range pending {
+		if s.inFlight[delivery.DeliveryID] {
+			continue
+		}
+
+		if circuit := s.circuits[webhookCircuitKey(delivery)]; circuit != nil && circuit.openUntil.After(now) {
+			delivery.NextAttemptAt = circuit.openUntil
+			s.save(ctx, delivery)
+			continue
+		}
+
+		s.inFlight[delivery.DeliveryID] = true
+		due = append(due, delivery)
+	}
+	return due
+}
+
+// release liberta uma entrega reclamada que não chegou a ser tentada
+func (s *WebhookDeliveryService) release(deliveryID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.inFlight, deliveryID)
+}
+
+// attempt envia a notificação e regista o resultado da tentativa
+func (s *WebhookDeliveryService) attempt(ctx context.Context, delivery *WebhookDelivery) {
+	ctx, span := s.tracer.StartSpan(ctx, "WebhookDeliveryService.attempt")
+	defer span.End()
+
+	statusCode, err := s.send(ctx, delivery)
+	if err != nil {
+		span.RecordError(err)
+	}
+	s.recordAttempt(ctx, delivery, statusCode, err)
+}
+
+// send assina e envia o evento ao endpoint do comerciante
+func (s *WebhookDeliveryService) send(ctx context.Context, delivery *WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao serializar evento: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("falha ao criar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event.EventType)
+
+	// O identificador da entrega é reutilizado em todas as tentativas para o comerciante descartar duplicados
+	req.Header.Set(WebhookIDHeader, delivery.DeliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(s.now().Unix(), 10))
+	if s.signer != nil {
+		headers, err := s.signer.SignWebhook(ctx, delivery.TenantID, delivery.MerchantID, delivery.DeliveryID, body)
+		switch {
+		case err == nil:
+			for name := range headers {
+				req.Header.Set(name, headers.Get(name))
+			}
+		case errors.Is(err, ErrWebhookKeyNoSigningKey):
+			// Comerciante ainda sem chave de assinatura: o webhook segue sem assinatura
+		default:
+			return 0, fmt.Errorf("falha ao assinar webhook: %w", err)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("falha na requisição: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint retornou status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordAttempt atualiza a entrega e o circuito do comerciante após uma tentativa
+func (s *WebhookDeliveryService) recordAttempt(ctx context.Context, delivery *WebhookDelivery, statusCode int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.inFlight, delivery.DeliveryID)
+
+	now := s.now()
+	policy := s.policyFor(delivery.MerchantID)
+	circuitKey := webhookCircuitKey(delivery)
+	circuit := s.circuits[circuitKey]
+	if circuit == nil {
+		circuit = &webhookCircuit{}
+		s.circuits[circuitKey] = circuit
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	defer s.save(ctx, delivery)
+
+	outcome := WebhookDeliveryDelivered
+	defer func() {
+		s.metricsRecorder.CounterInc("payment_gateway_webhook_attempts", map[string]string{
+			"event_type": delivery.Event.EventType,
+			"outcome":    outcome,
+		})
+	}()
+
+	if err == nil {
+		delivery.Status = WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		circuit.consecutiveFailures = 0
+		circuit.openUntil = time.Time{}
+		return
+	}
+
+	outcome = WebhookDeliveryFailed
+	delivery.LastError = err.Error()
+	circuit.consecutiveFailures++
+	if circuit.consecutiveFailures >= policy.FailureThreshold {
+		circuit.openUntil = now.Add(policy.CircuitCooldown)
+		s.logger.WarnWithContext(ctx, "Circuito de webhook aberto para comerciante",
+			"tenant_id", delivery.TenantID,
+			"merchant_id", delivery.MerchantID,
+			"consecutive_failures", circuit.consecutiveFailures,
+			"open_until", circuit.openUntil)
+	}
+
+	if delivery.Attempts >= policy.MaxAttempts {
+		delivery.Status = WebhookDeliveryFailed
+		s.logger.ErrorWithContext(ctx, "Entrega de webhook esgotou as tentativas",
+			"delivery_id", delivery.DeliveryID,
+			"merchant_id", delivery.MerchantID,
+			"event_type", delivery.Event.EventType,
+			"attempts", delivery.Attempts,
+			"error", err.Error())
+		return
+	}
+	delivery.NextAttemptAt = now.Add(policy.backoff(delivery.Attempts))
+}
+
+// save grava a entrega; uma falha mantém o estado anterior gravado, e a tentativa pode repetir-se
+func (s *WebhookDeliveryService) save(ctx context.Context, delivery *WebhookDelivery) {
+	if err := s.store.SaveDelivery(ctx, delivery); err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao gravar entrega de webhook",
+			"delivery_id", delivery.DeliveryID,
+			"merchant_id", delivery.MerchantID,
+			"error", err.Error())
+	}
+}
+
+// prune descarta as entregas concluídas há mais tempo que o período de retenção
+func (s *WebhookDeliveryService) prune(ctx context.Context) {
+	now := s.now()
+	if now.Sub(s.lastPrune) < webhookPruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	removed, err := s.store.DeleteCompletedDeliveries(ctx, now.Add(-s.config.Retention))
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Falha ao remover entregas de webhook concluídas", "error", err.Error())
+		return
+	}
+	if removed > 0 {
+		s.logger.InfoWithContext(ctx, "Entregas de webhook concluídas removidas", "removed", removed)
+	}
+}
+
+// policyFor retorna a política de novas tentativas do comerciante
+func (s *WebhookDeliveryService) policyFor(merchantID string) WebhookRetryPolicy {
+	if policy, exists := s.config.RetryPolicies[merchantID]; exists {
+		return policy.withDefaults()
+	}
+	return DefaultWebhookRetryPolicy
+}
+
+// webhookCircuitKey identifica o circuito do endpoint do comerciante no tenant
+func webhookCircuitKey(delivery *WebhookDelivery) string {
+	return delivery.TenantID + "/" + delivery.MerchantID
+}
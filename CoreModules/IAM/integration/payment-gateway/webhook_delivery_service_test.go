@@ -0,0 +1,357 @@
+package paymentgateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookSigner assina com um cabeçalho fixo ou devolve o erro configurado
+type fakeWebhookSigner struct {
+	err error
+}
+
+func (s fakeWebhookSigner) SignWebhook(ctx context.Context, tenantID, merchantID, webhookID string, payload []byte) (http.Header, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	headers := make(http.Header)
+	headers.Set(WebhookIDHeader, webhookID)
+	headers.Set(WebhookSignatureHeader, "v1,"+tenantID+"/"+merchantID)
+	return headers, nil
+}
+
+// webhookEndpoint simula o endpoint do comerciante e regista os pedidos recebidos
+type webhookEndpoint struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	events   []WebhookEvent
+}
+
+func newWebhookEndpoint(t *testing.T, status int) *webhookEndpoint {
+	t.Helper()
+
+	endpoint := &webhookEndpoint{status: status}
+	endpoint.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+
+		endpoint.mu.Lock()
+		defer endpoint.mu.Unlock()
+		endpoint.requests = append(endpoint.requests, r)
+		endpoint.events = append(endpoint.events, event)
+		w.WriteHeader(endpoint.status)
+	}))
+	t.Cleanup(endpoint.server.Close)
+	return endpoint
+}
+
+func (e *webhookEndpoint) setStatus(status int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status = status
+}
+
+func (e *webhookEndpoint) received() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+// newTestWebhookDeliveryService cria o serviço com um relógio controlado pelo teste
+func newTestWebhookDeliveryService(t *testing.T, config WebhookDeliveryConfig, signer WebhookSigner) (*WebhookDeliveryService, *InMemoryWebhookDeliveryStore, *time.Time) {
+	t.Helper()
+
+	store := NewInMemoryWebhookDeliveryStore()
+	service, err := NewWebhookDeliveryService(config, store, signer)
+	require.NoError(t, err)
+
+	clock := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return clock }
+	return service, store, &clock
+}
+
+func testWebhookEvent() WebhookEvent {
+	return WebhookEvent{
+		EventType:     WebhookEventTransactionCompleted,
+		TenantID:      "tenant-1",
+		MerchantID:    "merchant-1",
+		TransactionID: "tx-1",
+		Status:        TransactionStatusApproved,
+		PaymentMethod: PaymentMethodCard,
+		Amount:        100,
+		Currency:      "AOA",
+		Market:        RegionAngola,
+	}
+}
+
+func TestWebhookEnqueueWithoutEndpoint(t *testing.T) {
+	service, store, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{}, nil)
+
+	delivery, err := service.Enqueue(context.Background(), testWebhookEvent())
+	require.NoError(t, err)
+	assert.Nil(t, delivery)
+
+	deliveries, err := store.ListDeliveries(context.Background(), WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestWebhookDeliverySignedAndDelivered(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	service, store, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+	}, fakeWebhookSigner{})
+	ctx := context.Background()
+
+	delivery, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	require.NotNil(t, delivery)
+
+	service.dispatchDue(ctx)
+
+	require.Equal(t, 1, endpoint.received())
+	request := endpoint.requests[0]
+	assert.Equal(t, delivery.DeliveryID, request.Header.Get(WebhookIDHeader))
+	assert.Equal(t, WebhookEventTransactionCompleted, request.Header.Get(WebhookEventHeader))
+	assert.Equal(t, "v1,tenant-1/merchant-1", request.Header.Get(WebhookSignatureHeader))
+	assert.Equal(t, "tx-1", endpoint.events[0].TransactionID)
+	assert.Equal(t, delivery.Event.EventID, endpoint.events[0].EventID)
+
+	stored, err := store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryDelivered, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, http.StatusOK, stored.LastStatusCode)
+	assert.NotNil(t, stored.DeliveredAt)
+}
+
+func TestWebhookDeliveryWithoutSigningKeyIsSentUnsigned(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	service, _, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+	}, fakeWebhookSigner{err: ErrWebhookKeyNoSigningKey})
+	ctx := context.Background()
+
+	delivery, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	service.dispatchDue(ctx)
+
+	require.Equal(t, 1, endpoint.received())
+	assert.Equal(t, delivery.DeliveryID, endpoint.requests[0].Header.Get(WebhookIDHeader))
+	assert.Empty(t, endpoint.requests[0].Header.Get(WebhookSignatureHeader))
+}
+
+func TestWebhookDeliveryRetriesWithBackoff(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusInternalServerError)
+	service, store, clock := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+		RetryPolicies: map[string]WebhookRetryPolicy{
+			"merchant-1": {MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: 90 * time.Second, Multiplier: 2},
+		},
+	}, nil)
+	ctx := context.Background()
+
+	delivery, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+
+	// Primeira falha: nova tentativa após o backoff inicial
+	service.dispatchDue(ctx)
+	stored, err := store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, stored.LastStatusCode)
+	assert.Equal(t, clock.Add(time.Minute), stored.NextAttemptAt)
+
+	// Antes do horário a entrega não é tentada
+	service.dispatchDue(ctx)
+	assert.Equal(t, 1, endpoint.received())
+
+	// Segunda falha: o backoff é limitado por MaxBackoff
+	*clock = clock.Add(time.Minute)
+	service.dispatchDue(ctx)
+	stored, err = store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Attempts)
+	assert.Equal(t, clock.Add(90*time.Second), stored.NextAttemptAt)
+
+	// Terceira falha esgota as tentativas
+	*clock = clock.Add(90 * time.Second)
+	service.dispatchDue(ctx)
+	stored, err = store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryFailed, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+	assert.Equal(t, 3, endpoint.received())
+}
+
+func TestWebhookDeliveryCircuitBreaker(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusServiceUnavailable)
+	service, store, clock := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+		RetryPolicies: map[string]WebhookRetryPolicy{
+			"merchant-1": {InitialBackoff: time.Second, FailureThreshold: 1, CircuitCooldown: 10 * time.Minute},
+		},
+	}, nil)
+	ctx := context.Background()
+
+	first, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	service.dispatchDue(ctx)
+	require.Equal(t, 1, endpoint.received())
+
+	// Com o circuito aberto, as entregas são adiadas sem consumir tentativas
+	second, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	*clock = clock.Add(time.Second)
+	service.dispatchDue(ctx)
+	assert.Equal(t, 1, endpoint.received())
+
+	stored, err := store.GetDelivery(ctx, "tenant-1", second.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Attempts)
+	assert.Equal(t, clock.Add(10*time.Minute-time.Second), stored.NextAttemptAt)
+
+	// Após o período de espera as entregas seguem e um sucesso fecha o circuito
+	endpoint.setStatus(http.StatusOK)
+	*clock = clock.Add(10 * time.Minute)
+	service.dispatchDue(ctx)
+	assert.Equal(t, 3, endpoint.received())
+
+	for _, id := range []string{first.DeliveryID, second.DeliveryID} {
+		stored, err := store.GetDelivery(ctx, "tenant-1", id)
+		require.NoError(t, err)
+		assert.Equal(t, WebhookDeliveryDelivered, stored.Status)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusInternalServerError)
+	service, store, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints:     map[string]string{"merchant-1": endpoint.server.URL},
+		RetryPolicies: map[string]WebhookRetryPolicy{"merchant-1": {MaxAttempts: 1}},
+	}, nil)
+	ctx := context.Background()
+
+	delivery, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	service.dispatchDue(ctx)
+
+	stored, err := store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryFailed, stored.Status)
+
+	_, err = service.Redeliver(ctx, "tenant-2", delivery.DeliveryID)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+
+	redelivered, err := service.Redeliver(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryPending, redelivered.Status)
+	assert.Equal(t, 0, redelivered.Attempts)
+	assert.Equal(t, 1, redelivered.Redeliveries)
+
+	endpoint.setStatus(http.StatusOK)
+	service.dispatchDue(ctx)
+	stored, err = store.GetDelivery(ctx, "tenant-1", delivery.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryDelivered, stored.Status)
+}
+
+func TestWebhookPruneRemovesCompletedDeliveries(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	service, store, clock := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+		Retention: time.Hour,
+	}, nil)
+	ctx := context.Background()
+
+	delivered, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	service.dispatchDue(ctx)
+
+	*clock = clock.Add(2 * time.Hour)
+	pending, err := service.Enqueue(ctx, testWebhookEvent())
+	require.NoError(t, err)
+	service.prune(ctx)
+
+	_, err = store.GetDelivery(ctx, "tenant-1", delivered.DeliveryID)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+	_, err = store.GetDelivery(ctx, "tenant-1", pending.DeliveryID)
+	assert.NoError(t, err)
+}
+
+func TestWebhookDeliveryHandler(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	service, _, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+	}, nil)
+	delivery, err := service.Enqueue(context.Background(), testWebhookEvent())
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	NewWebhookDeliveryHandler(service).RegisterRoutes(router)
+
+	t.Run("lista as entregas do tenant", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/support/webhooks/deliveries?status=pending", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, asSupportOperator(req, "ana"))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var deliveries []WebhookDelivery
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, delivery.DeliveryID, deliveries[0].DeliveryID)
+	})
+
+	t.Run("reentrega de outro tenant não é encontrada", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/support/webhooks/deliveries/"+delivery.DeliveryID+"/redeliver", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-2")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, asSupportOperator(req, "ana"))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("reentrega aceite", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/support/webhooks/deliveries/"+delivery.DeliveryID+"/redeliver", nil)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, asSupportOperator(req, "ana"))
+
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		var redelivered WebhookDelivery
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&redelivered))
+		assert.Equal(t, 1, redelivered.Redeliveries)
+	})
+}
+
+func TestProcessPaymentNotifiesMerchant(t *testing.T) {
+	endpoint := newWebhookEndpoint(t, http.StatusOK)
+	service, store, _ := newTestWebhookDeliveryService(t, WebhookDeliveryConfig{
+		Endpoints: map[string]string{"merchant-1": endpoint.server.URL},
+	}, nil)
+	connector, _ := newTestConnector(t)
+	connector.SetWebhookDeliveryService(service)
+
+	response, err := connector.ProcessPayment(context.Background(), testRiskRequest(RegionAngola, "AOA", 1000))
+	require.NoError(t, err)
+	require.Equal(t, TransactionStatusApproved, response.Status)
+
+	deliveries, err := store.ListDeliveries(context.Background(), WebhookDeliveryFilter{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, WebhookEventTransactionCompleted, deliveries[0].Event.EventType)
+	assert.Equal(t, response.TransactionID, deliveries[0].Event.TransactionID)
+}
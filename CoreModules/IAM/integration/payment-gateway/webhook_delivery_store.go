@@ -0,0 +1,120 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebhookDeliveryStore define a persistência das entregas de webhook, retomadas após um reinício
+type WebhookDeliveryStore interface {
+	// SaveDelivery grava a entrega, substituindo o estado anterior
+	SaveDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
+	// GetDelivery recupera a entrega do tenant; retorna ErrWebhookDeliveryNotFound quando não existe
+	GetDelivery(ctx context.Context, tenantID, deliveryID string) (*WebhookDelivery, error)
+
+	// ListDeliveries lista as entregas filtradas, das mais recentes às mais antigas
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+
+	// ListDueDeliveries lista as entregas pendentes cuja próxima tentativa é até ao instante indicado
+	ListDueDeliveries(ctx context.Context, until time.Time) ([]*WebhookDelivery, error)
+
+	// DeleteCompletedDeliveries remove as entregas concluídas criadas antes do instante indicado
+	DeleteCompletedDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+// InMemoryWebhookDeliveryStore armazena as entregas de webhook em memória
+type InMemoryWebhookDeliveryStore struct {
+	deliveries map[string]*WebhookDelivery
+	mutex      sync.RWMutex
+}
+
+// NewInMemoryWebhookDeliveryStore cria um novo armazenamento em memória
+func NewInMemoryWebhookDeliveryStore() *InMemoryWebhookDeliveryStore {
+	return &InMemoryWebhookDeliveryStore{deliveries: make(map[string]*WebhookDelivery)}
+}
+
+// SaveDelivery grava uma cópia da entrega
+func (s *InMemoryWebhookDeliveryStore) SaveDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.deliveries[delivery.DeliveryID] = copyWebhookDelivery(delivery)
+	return nil
+}
+
+// GetDelivery retorna uma cópia da entrega do tenant
+func (s *InMemoryWebhookDeliveryStore) GetDelivery(ctx context.Context, tenantID, deliveryID string) (*WebhookDelivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok || delivery.TenantID != tenantID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return copyWebhookDelivery(delivery), nil
+}
+
+// ListDeliveries lista cópias das entregas filtradas
+func (s *InMemoryWebhookDeliveryStore) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.MerchantID != "" && delivery.MerchantID != filter.MerchantID {
+			continue
+		}
+		if filter.Status != "" && delivery.Status != filter.Status {
+			continue
+		}
+		result = append(result, copyWebhookDelivery(delivery))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// ListDueDeliveries lista cópias das entregas pendentes vencidas, das mais antigas às mais recentes
+func (s *InMemoryWebhookDeliveryStore) ListDueDeliveries(ctx context.Context, until time.Time) ([]*WebhookDelivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*WebhookDelivery, 0)
+	for _, delivery := range s.deliveries {
+		if delivery.Status == WebhookDeliveryPending && !delivery.NextAttemptAt.After(until) {
+			result = append(result, copyWebhookDelivery(delivery))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NextAttemptAt.Before(result[j].NextAttemptAt) })
+	return result, nil
+}
+
+// DeleteCompletedDeliveries remove as entregas entregues ou falhadas criadas antes do instante indicado
+func (s *InMemoryWebhookDeliveryStore) DeleteCompletedDeliveries(ctx context.Context, before time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removed := 0
+	for id, delivery := range s.deliveries {
+		if delivery.Status != WebhookDeliveryPending && delivery.CreatedAt.Before(before) {
+			delete(s.deliveries, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// copyWebhookDelivery copia a entrega e a data de entrega
+func copyWebhookDelivery(delivery *WebhookDelivery) *WebhookDelivery {
+	copied := *delivery
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		copied.DeliveredAt = &deliveredAt
+	}
+	return &copied
+}
@@ -0,0 +1,144 @@
+package paymentgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookKeyHandler expõe a API HTTP das chaves de assinatura dos webhooks dos comerciantes
+type WebhookKeyHandler struct {
+	service *WebhookKeyService
+}
+
+// NewWebhookKeyHandler cria uma nova instância do WebhookKeyHandler
+func NewWebhookKeyHandler(service *WebhookKeyService) *WebhookKeyHandler {
+	return &WebhookKeyHandler{service: service}
+}
+
+// RegisterRoutes registra as rotas do handler no router fornecido
+func (h *WebhookKeyHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/merchants/{merchantId}/webhook-keys", h.ListKeys).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}/webhook-keys/rotate", h.RotateKey).Methods(http.MethodPost)
+	router.HandleFunc("/merchants/{merchantId}/webhook-keys/verification", h.VerificationKeys).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}/webhook-keys/{keyId}/secret", h.GetKeySecret).Methods(http.MethodGet)
+	router.HandleFunc("/merchants/{merchantId}/webhook-keys/{keyId}/revoke", h.RevokeKey).Methods(http.MethodPost)
+}
+
+// webhookKeyRotationRequest representa a requisição de rotação da chave de um comerciante
+type webhookKeyRotationRequest struct {
+	Algorithm    string `json:"algorithm,omitempty"`
+	OverlapHours *int   `json:"overlap_hours,omitempty"`
+	Immediate    bool   `json:"immediate,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// webhookKeyRevokeRequest representa a requisição de revogação de uma chave
+type webhookKeyRevokeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RotateKey cria uma nova chave ativa; a resposta inclui o segredo HMAC da nova chave
+func (h *WebhookKeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	var body webhookKeyRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	req := &WebhookKeyRotationRequest{
+		TenantID:   r.Header.Get("X-Tenant-ID"),
+		MerchantID: mux.Vars(r)["merchantId"],
+		Algorithm:  body.Algorithm,
+		Immediate:  body.Immediate,
+		Reason:     body.Reason,
+		ActorID:    r.Header.Get("X-User-ID"),
+	}
+	if body.OverlapHours != nil {
+		if *body.OverlapHours <= 0 {
+			respondWithError(w, http.StatusBadRequest, "invalid_request", "Parâmetro overlap_hours inválido")
+			return
+		}
+		req.Overlap = time.Duration(*body.OverlapHours) * time.Hour
+	}
+
+	result, err := h.service.RotateKey(r.Context(), req)
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, result)
+}
+
+// ListKeys lista todas as chaves do comerciante, incluindo as retiradas e revogadas
+func (h *WebhookKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// VerificationKeys lista as chaves que o comerciante deve aceitar, com as chaves públicas Ed25519
+func (h *WebhookKeyHandler) VerificationKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.VerificationKeys(r.Context(), r.Header.Get("X-Tenant-ID"), mux.Vars(r)["merchantId"])
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// GetKeySecret devolve o segredo HMAC de uma chave que ainda assina os webhooks
+func (h *WebhookKeyHandler) GetKeySecret(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	secret, err := h.service.GetKeySecret(r.Context(), r.Header.Get("X-Tenant-ID"), vars["merchantId"], vars["keyId"], r.Header.Get("X-User-ID"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, secret)
+}
+
+// RevokeKey revoga de imediato uma chave do comerciante
+func (h *WebhookKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	var body webhookKeyRevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		return
+	}
+
+	vars := mux.Vars(r)
+	key, err := h.service.RevokeKey(r.Context(), r.Header.Get("X-Tenant-ID"), vars["merchantId"], vars["keyId"], body.Reason, r.Header.Get("X-User-ID"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, key)
+}
+
+// respondWithServiceError converte erros do serviço em respostas HTTP
+func (h *WebhookKeyHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrWebhookKeyInvalid), errors.Is(err, ErrWebhookKeySecretNotVisible):
+		respondWithError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, ErrMerchantNotFound):
+		respondWithError(w, http.StatusNotFound, "merchant_not_found", err.Error())
+	case errors.Is(err, ErrWebhookKeyNotFound):
+		respondWithError(w, http.StatusNotFound, "webhook_key_not_found", err.Error())
+	case errors.Is(err, ErrWebhookKeyStateInvalid):
+		respondWithError(w, http.StatusConflict, "invalid_key_state", err.Error())
+	default:
+		respondWithError(w, http.StatusInternalServerError, "webhook_key_error", err.Error())
+	}
+}
@@ -0,0 +1,145 @@
+package paymentgateway
+
+import (
+	"errors"
+	"time"
+)
+
+// Algoritmos das chaves de assinatura dos webhooks
+const (
+	WebhookKeyAlgorithmHMACSHA256 = "hmac-sha256" // Segredo partilhado com o comerciante
+	WebhookKeyAlgorithmEd25519    = "ed25519"     // Par de chaves; o comerciante recebe só a chave pública
+)
+
+// Estados das chaves de assinatura dos webhooks
+const (
+	WebhookKeyStatusActive   = "active"   // Assina os webhooks do comerciante
+	WebhookKeyStatusRetiring = "retiring" // Substituída; continua a assinar até ao fim da janela de sobreposição
+	WebhookKeyStatusRetired  = "retired"  // Janela de sobreposição terminada
+	WebhookKeyStatusRevoked  = "revoked"  // Retirada de imediato (ex: comprometimento)
+)
+
+// Versões das assinaturas enviadas no cabeçalho X-Webhook-Signature, segundo o Standard Webhooks
+// O conteúdo assinado é "<webhook_id>.<timestamp>.<payload>" em todas as versões
+const (
+	WebhookSignatureVersionHMAC    = "v1"  // HMAC-SHA256
+	WebhookSignatureVersionEd25519 = "v1a" // Ed25519
+)
+
+// Cabeçalhos dos webhooks assinados
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// Prefixos das chaves entregues aos comerciantes
+const (
+	webhookSecretPrefix    = "whsec_"
+	webhookPublicKeyPrefix = "whpk_"
+)
+
+// Valores padrão da gestão das chaves dos webhooks
+const (
+	DefaultWebhookKeyAlgorithm  = WebhookKeyAlgorithmHMACSHA256
+	DefaultWebhookKeyOverlap    = 24 * time.Hour
+	DefaultWebhookKeyMaxOverlap = 7 * 24 * time.Hour
+	webhookHMACSecretBytes      = 32
+)
+
+// Erros da gestão das chaves dos webhooks
+var (
+	ErrWebhookKeyNotFound         = errors.New("chave de assinatura de webhooks não encontrada")
+	ErrWebhookKeyInvalid          = errors.New("pedido de chave de assinatura de webhooks inválido")
+	ErrWebhookKeyStateInvalid     = errors.New("estado da chave de assinatura não permite a operação")
+	ErrWebhookKeyNoSigningKey     = errors.New("comerciante sem chave de assinatura de webhooks ativa")
+	ErrWebhookKeyConfigInvalid    = errors.New("configuração das chaves de webhooks inválida")
+	ErrWebhookKeySecretNotVisible = errors.New("chave Ed25519 sem segredo partilhado; use a chave pública")
+)
+
+// WebhookSigningKey é uma chave de assinatura dos webhooks de um comerciante
+// O material secreto (segredo HMAC ou semente Ed25519) só é guardado cifrado com a chave mestra,
+// associado ao identificador da chave
+type WebhookSigningKey struct {
+	KeyID            string     `json:"key_id" db:"key_id"`
+	TenantID         string     `json:"tenant_id" db:"tenant_id"`
+	MerchantID       string     `json:"merchant_id" db:"merchant_id"`
+	Algorithm        string     `json:"algorithm" db:"algorithm"`
+	Status           string     `json:"status" db:"status"`
+	SecretCiphertext []byte     `json:"-" db:"secret_ciphertext"`
+	PublicKey        string     `json:"public_key,omitempty" db:"public_key"` // Apenas Ed25519
+	CreatedBy        string     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	RetiresAt        *time.Time `json:"retires_at,omitempty" db:"retires_at"` // Fim da janela de sobreposição
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason    string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// SignatureVersion retorna a versão da assinatura produzida pela chave
+func (k *WebhookSigningKey) SignatureVersion() string {
+	if k.Algorithm == WebhookKeyAlgorithmEd25519 {
+		return WebhookSignatureVersionEd25519
+	}
+	return WebhookSignatureVersionHMAC
+}
+
+// EffectiveStatus retorna o estado da chave no instante indicado; uma chave em substituição
+// cuja janela de sobreposição terminou é considerada retirada
+func (k *WebhookSigningKey) EffectiveStatus(now time.Time) string {
+	if k.Status == WebhookKeyStatusRetiring && k.RetiresAt != nil && !now.Before(*k.RetiresAt) {
+		return WebhookKeyStatusRetired
+	}
+	return k.Status
+}
+
+// Signing indica se a chave assina os webhooks no instante indicado
+func (k *WebhookSigningKey) Signing(now time.Time) bool {
+	status := k.EffectiveStatus(now)
+	return status == WebhookKeyStatusActive || status == WebhookKeyStatusRetiring
+}
+
+// WebhookKeyRotationRequest representa a criação de uma nova chave ativa para o comerciante
+// A chave ativa anterior continua a assinar durante a janela de sobreposição; sem Overlap usa-se
+// a janela padrão e com Immediate a chave anterior é revogada de imediato
+type WebhookKeyRotationRequest struct {
+	TenantID   string        `json:"-"`
+	MerchantID string        `json:"-"`
+	Algorithm  string        `json:"algorithm,omitempty"`
+	Overlap    time.Duration `json:"-"`
+	Immediate  bool          `json:"immediate,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	ActorID    string        `json:"actor_id,omitempty"`
+}
+
+// WebhookKeyRotationResult devolve a nova chave e o seu segredo, mostrado apenas nesta resposta
+// e na consulta explícita do segredo
+type WebhookKeyRotationResult struct {
+	Key      *WebhookSigningKey   `json:"key"`
+	Secret   string               `json:"secret,omitempty"` // Apenas HMAC, com o prefixo whsec_
+	Retiring []*WebhookSigningKey `json:"retiring,omitempty"`
+}
+
+// WebhookKeySecret é o segredo HMAC de uma chave entregue ao comerciante
+type WebhookKeySecret struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Secret    string `json:"secret"`
+}
+
+// WebhookKeyConfig contém as configurações da gestão das chaves dos webhooks
+type WebhookKeyConfig struct {
+	// Chave mestra AES-256 que cifra o material secreto das chaves em repouso (32 bytes)
+	MasterKey []byte `json:"-"`
+
+	// Algoritmo das novas chaves quando o pedido não o indica
+	DefaultAlgorithm string `json:"default_algorithm"`
+
+	// Janela de sobreposição padrão e máxima das rotações
+	DefaultOverlap time.Duration `json:"default_overlap"`
+	MaxOverlap     time.Duration `json:"max_overlap"`
+}
+
+// validWebhookKeyAlgorithm verifica se o algoritmo é suportado
+func validWebhookKeyAlgorithm(algorithm string) bool {
+	return algorithm == WebhookKeyAlgorithmHMACSHA256 || algorithm == WebhookKeyAlgorithmEd25519
+}
@@ -0,0 +1,128 @@
+package paymentgateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresWebhookKeyStore implementa WebhookKeyStore para PostgreSQL
+type PostgresWebhookKeyStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresWebhookKeyStore cria uma nova instância de PostgresWebhookKeyStore
+func NewPostgresWebhookKeyStore(db *sqlx.DB) *PostgresWebhookKeyStore {
+	return &PostgresWebhookKeyStore{db: db}
+}
+
+// webhookKeyColumns são as colunas lidas de payment_gateway.webhook_signing_keys
+const webhookKeyColumns = `
+	key_id, tenant_id, merchant_id, algorithm, status, secret_ciphertext, public_key, created_by,
+	created_at, retires_at, revoked_at, revoked_reason
+`
+
+// updateWebhookKeyQuery grava o estado de uma chave existente
+const updateWebhookKeyQuery = `
+	UPDATE payment_gateway.webhook_signing_keys SET
+		status = :status,
+		retires_at = :retires_at,
+		revoked_at = :revoked_at,
+		revoked_reason = :revoked_reason
+	WHERE key_id = :key_id AND tenant_id = :tenant_id AND merchant_id = :merchant_id
+`
+
+// SaveRotation altera as chaves anteriores e grava a nova chave numa única transação
+// O índice único da chave ativa rejeita rotações concorrentes do mesmo comerciante
+func (r *PostgresWebhookKeyStore) SaveRotation(ctx context.Context, key *WebhookSigningKey, previous []*WebhookSigningKey) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("falha ao iniciar transação da rotação de chaves: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, prev := range previous {
+		if err := execWebhookKeyUpdate(ctx, tx, prev); err != nil {
+			return err
+		}
+	}
+
+	insert := `
+		INSERT INTO payment_gateway.webhook_signing_keys (
+			key_id, tenant_id, merchant_id, algorithm, status, secret_ciphertext, public_key, created_by,
+			created_at, retires_at, revoked_at, revoked_reason
+		) VALUES (
+			:key_id, :tenant_id, :merchant_id, :algorithm, :status, :secret_ciphertext, :public_key, :created_by,
+			:created_at, :retires_at, :revoked_at, :revoked_reason
+		)
+	`
+	if _, err := tx.NamedExecContext(ctx, insert, key); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: rotação concorrente da chave ativa", ErrWebhookKeyStateInvalid)
+		}
+		return fmt.Errorf("falha ao gravar chave de assinatura de webhooks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("falha ao confirmar rotação de chaves: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateKey grava o estado de uma chave existente
+func (r *PostgresWebhookKeyStore) UpdateKey(ctx context.Context, key *WebhookSigningKey) error {
+	return execWebhookKeyUpdate(ctx, r.db, key)
+}
+
+// GetKey recupera uma chave do comerciante, ou nil se não existir
+func (r *PostgresWebhookKeyStore) GetKey(ctx context.Context, tenantID, merchantID, keyID string) (*WebhookSigningKey, error) {
+	query := `SELECT ` + webhookKeyColumns + ` FROM payment_gateway.webhook_signing_keys
+		WHERE tenant_id = $1 AND merchant_id = $2 AND key_id = $3`
+
+	var key WebhookSigningKey
+	if err := r.db.GetContext(ctx, &key, query, tenantID, merchantID, keyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao recuperar chave de assinatura de webhooks: %w", err)
+	}
+
+	return &key, nil
+}
+
+// ListKeys lista as chaves do comerciante, da mais recente para a mais antiga
+func (r *PostgresWebhookKeyStore) ListKeys(ctx context.Context, tenantID, merchantID string) ([]*WebhookSigningKey, error) {
+	query := `SELECT ` + webhookKeyColumns + ` FROM payment_gateway.webhook_signing_keys
+		WHERE tenant_id = $1 AND merchant_id = $2
+		ORDER BY created_at DESC`
+
+	keys := make([]*WebhookSigningKey, 0)
+	if err := r.db.SelectContext(ctx, &keys, query, tenantID, merchantID); err != nil {
+		return nil, fmt.Errorf("falha ao listar chaves de assinatura de webhooks: %w", err)
+	}
+
+	return keys, nil
+}
+
+// execWebhookKeyUpdate atualiza uma chave e retorna ErrWebhookKeyNotFound se nenhuma linha for afetada
+func execWebhookKeyUpdate(ctx context.Context, db sqlx.ExtContext, key *WebhookSigningKey) error {
+	result, err := sqlx.NamedExecContext(ctx, db, updateWebhookKeyQuery, key)
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar chave de assinatura de webhooks: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar chave de assinatura de webhooks: %w", err)
+	}
+	if rows == 0 {
+		return ErrWebhookKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,411 @@
+package paymentgateway
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabizdevops/innovabiz-iam/observability/adapter"
+	"github.com/innovabizdevops/innovabiz-iam/observability/logging"
+	"github.com/innovabizdevops/innovabiz-iam/observability/metrics"
+	"github.com/innovabizdevops/innovabiz-iam/observability/tracing"
+)
+
+// WebhookKeyService gere as chaves de assinatura dos webhooks de cada comerciante e assina os
+// webhooks enviados. Cada comerciante tem uma chave ativa; numa rotação, a chave anterior continua
+// a assinar durante a janela de sobreposição, para que o comerciante atualize a verificação sem
+// rejeitar webhooks. O material secreto é guardado cifrado com AES-256-GCM
+type WebhookKeyService struct {
+	config    WebhookKeyConfig
+	store     WebhookKeyStore
+	merchants MerchantRepository
+	aead      cipher.AEAD
+
+	logger          logging.Logger
+	tracer          tracing.Tracer
+	metricsRecorder metrics.Metrics
+	now             func() time.Time
+}
+
+// NewWebhookKeyService cria o serviço de gestão das chaves dos webhooks
+func NewWebhookKeyService(config WebhookKeyConfig, store WebhookKeyStore, merchants MerchantRepository) (*WebhookKeyService, error) {
+	obsAdapter, err := adapter.NewAdapter(adapter.Config{
+		ServiceName: "payment-gateway-webhook-keys",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar observabilidade: %w", err)
+	}
+
+	if len(config.MasterKey) != 32 {
+		return nil, fmt.Errorf("%w: a chave mestra deve ter 32 bytes", ErrWebhookKeyConfigInvalid)
+	}
+	if config.DefaultAlgorithm == "" {
+		config.DefaultAlgorithm = DefaultWebhookKeyAlgorithm
+	}
+	if !validWebhookKeyAlgorithm(config.DefaultAlgorithm) {
+		return nil, fmt.Errorf("%w: algoritmo %q", ErrWebhookKeyConfigInvalid, config.DefaultAlgorithm)
+	}
+	if config.DefaultOverlap <= 0 {
+		config.DefaultOverlap = DefaultWebhookKeyOverlap
+	}
+	if config.MaxOverlap <= 0 {
+		config.MaxOverlap = DefaultWebhookKeyMaxOverlap
+	}
+	if config.DefaultOverlap > config.MaxOverlap {
+		return nil, fmt.Errorf("%w: janela de sobreposição padrão acima da máxima", ErrWebhookKeyConfigInvalid)
+	}
+
+	block, err := aes.NewCipher(config.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookKeyConfigInvalid, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookKeyConfigInvalid, err)
+	}
+
+	return &WebhookKeyService{
+		config:          config,
+		store:           store,
+		merchants:       merchants,
+		aead:            aead,
+		logger:          obsAdapter.Logger(),
+		tracer:          obsAdapter.Tracer(),
+		metricsRecorder: obsAdapter.Metrics(),
+		now:             time.Now,
+	}, nil
+}
+
+// SetClock substitui o relógio usado nas rotações e na janela de sobreposição
+func (s *WebhookKeyService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// RotateKey cria uma nova chave ativa para o comerciante
+// A chave ativa anterior passa a estar em substituição até ao fim da janela de sobreposição; com
+// Immediate, todas as chaves que ainda assinam são revogadas (ex: suspeita de comprometimento)
+func (s *WebhookKeyService) RotateKey(ctx context.Context, req *WebhookKeyRotationRequest) (*WebhookKeyRotationResult, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "WebhookKeyService.RotateKey")
+	defer span.End()
+
+	if req.TenantID == "" || req.MerchantID == "" {
+		return nil, ErrWebhookKeyInvalid
+	}
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = s.config.DefaultAlgorithm
+	}
+	if !validWebhookKeyAlgorithm(algorithm) {
+		return nil, fmt.Errorf("%w: algoritmo %q", ErrWebhookKeyInvalid, algorithm)
+	}
+	overlap := req.Overlap
+	if overlap == 0 {
+		overlap = s.config.DefaultOverlap
+	}
+	if !req.Immediate && (overlap < 0 || overlap > s.config.MaxOverlap) {
+		return nil, fmt.Errorf("%w: janela de sobreposição fora de [0, %s]", ErrWebhookKeyInvalid, s.config.MaxOverlap)
+	}
+
+	if _, err := s.merchants.GetMerchant(ctx, req.TenantID, req.MerchantID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.store.ListKeys(ctx, req.TenantID, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	retiresAt := now.Add(overlap)
+	previous := make([]*WebhookSigningKey, 0)
+	retiring := make([]*WebhookSigningKey, 0)
+	for _, key := range existing {
+		switch {
+		case key.Status == WebhookKeyStatusRetiring && !key.Signing(now):
+			// A janela terminou: o estado efetivo fica gravado
+			key.Status = WebhookKeyStatusRetired
+		case req.Immediate && key.Signing(now):
+			revokedAt := now
+			key.Status = WebhookKeyStatusRevoked
+			key.RevokedAt = &revokedAt
+			key.RevokedReason = req.Reason
+		case key.Status == WebhookKeyStatusActive:
+			key.Status = WebhookKeyStatusRetiring
+			key.RetiresAt = &retiresAt
+			retiring = append(retiring, key)
+		default:
+			continue
+		}
+		previous = append(previous, key)
+	}
+
+	key := &WebhookSigningKey{
+		KeyID:      fmt.Sprintf("whk-%s", uuid.New().String()),
+		TenantID:   req.TenantID,
+		MerchantID: req.MerchantID,
+		Algorithm:  algorithm,
+		Status:     WebhookKeyStatusActive,
+		CreatedBy:  req.ActorID,
+		CreatedAt:  now,
+	}
+
+	material, secret, err := s.generateKeyMaterial(key)
+	if err != nil {
+		return nil, err
+	}
+	if key.SecretCiphertext, err = s.seal(key.KeyID, material); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SaveRotation(ctx, key, previous); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_webhook_key_rotations_total", map[string]string{
+		"algorithm": algorithm,
+		"immediate": strconv.FormatBool(req.Immediate),
+	})
+
+	s.logger.InfoWithContext(ctx, "Chave de assinatura de webhooks rodada",
+		"merchant_id", req.MerchantID,
+		"key_id", key.KeyID,
+		"algorithm", algorithm,
+		"retiring_keys", len(retiring),
+		"immediate", req.Immediate,
+		"actor_id", req.ActorID)
+
+	return &WebhookKeyRotationResult{
+		Key:      key,
+		Secret:   secret,
+		Retiring: retiring,
+	}, nil
+}
+
+// RevokeKey revoga de imediato uma chave que ainda assina os webhooks
+// Revogar a chave ativa deixa o comerciante sem assinatura até à próxima rotação
+func (s *WebhookKeyService) RevokeKey(ctx context.Context, tenantID, merchantID, keyID, reason, actorID string) (*WebhookSigningKey, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "WebhookKeyService.RevokeKey")
+	defer span.End()
+
+	key, err := s.getKey(ctx, tenantID, merchantID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if !key.Signing(now) {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookKeyStateInvalid, key.EffectiveStatus(now))
+	}
+
+	key.Status = WebhookKeyStatusRevoked
+	key.RevokedAt = &now
+	key.RevokedReason = reason
+	if err := s.store.UpdateKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.metricsRecorder.CounterInc("payment_gateway_webhook_key_revocations_total", map[string]string{
+		"algorithm": key.Algorithm,
+	})
+
+	s.logger.WarnWithContext(ctx, "Chave de assinatura de webhooks revogada",
+		"merchant_id", merchantID,
+		"key_id", keyID,
+		"reason", reason,
+		"actor_id", actorID)
+
+	return key, nil
+}
+
+// ListKeys lista todas as chaves do comerciante com o estado efetivo
+func (s *WebhookKeyService) ListKeys(ctx context.Context, tenantID, merchantID string) ([]*WebhookSigningKey, error) {
+	if tenantID == "" || merchantID == "" {
+		return nil, ErrWebhookKeyInvalid
+	}
+	keys, err := s.store.ListKeys(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, key := range keys {
+		key.Status = key.EffectiveStatus(now)
+	}
+	return keys, nil
+}
+
+// VerificationKeys lista as chaves que o comerciante deve aceitar na verificação dos webhooks:
+// a chave ativa e as chaves em substituição dentro da janela de sobreposição
+func (s *WebhookKeyService) VerificationKeys(ctx context.Context, tenantID, merchantID string) ([]*WebhookSigningKey, error) {
+	keys, err := s.ListKeys(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	signing := make([]*WebhookSigningKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Status == WebhookKeyStatusActive || key.Status == WebhookKeyStatusRetiring {
+			signing = append(signing, key)
+		}
+	}
+	return signing, nil
+}
+
+// GetKeySecret devolve o segredo HMAC de uma chave que ainda assina os webhooks
+func (s *WebhookKeyService) GetKeySecret(ctx context.Context, tenantID, merchantID, keyID, actorID string) (*WebhookKeySecret, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "WebhookKeyService.GetKeySecret")
+	defer span.End()
+
+	key, err := s.getKey(ctx, tenantID, merchantID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != WebhookKeyAlgorithmHMACSHA256 {
+		return nil, ErrWebhookKeySecretNotVisible
+	}
+	if !key.Signing(s.now()) {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookKeyStateInvalid, key.EffectiveStatus(s.now()))
+	}
+
+	material, err := s.open(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithContext(ctx, "Segredo de assinatura de webhooks consultado",
+		"merchant_id", merchantID,
+		"key_id", keyID,
+		"actor_id", actorID)
+
+	return &WebhookKeySecret{
+		KeyID:     key.KeyID,
+		Algorithm: key.Algorithm,
+		Secret:    webhookSecretPrefix + base64.StdEncoding.EncodeToString(material),
+	}, nil
+}
+
+// SignWebhook assina o corpo de um webhook do comerciante com todas as chaves que assinam no
+// momento e devolve os cabeçalhos a enviar. Sem webhookID é gerado um identificador novo; as
+// novas tentativas de entrega devem reutilizar o mesmo identificador
+func (s *WebhookKeyService) SignWebhook(ctx context.Context, tenantID, merchantID, webhookID string, payload []byte) (http.Header, error) {
+	ctx, span := s.tracer.StartSpan(ctx, "WebhookKeyService.SignWebhook")
+	defer span.End()
+
+	keys, err := s.store.ListKeys(ctx, tenantID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if webhookID == "" {
+		webhookID = fmt.Sprintf("whm-%s", uuid.New().String())
+	}
+
+	now := s.now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	content := webhookSignedContent(webhookID, timestamp, payload)
+
+	// A chave ativa é a mais recente e assina primeiro
+	signatures := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !key.Signing(now) {
+			continue
+		}
+		material, err := s.open(key)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, key.SignatureVersion()+","+signWebhookContent(key.Algorithm, material, content))
+	}
+	if len(signatures) == 0 {
+		return nil, ErrWebhookKeyNoSigningKey
+	}
+
+	headers := make(http.Header)
+	headers.Set(WebhookIDHeader, webhookID)
+	headers.Set(WebhookTimestampHeader, timestamp)
+	headers.Set(WebhookSignatureHeader, strings.Join(signatures, " "))
+	return headers, nil
+}
+
+// getKey recupera uma chave do comerciante ou ErrWebhookKeyNotFound
+func (s *WebhookKeyService) getKey(ctx context.Context, tenantID, merchantID, keyID string) (*WebhookSigningKey, error) {
+	key, err := s.store.GetKey(ctx, tenantID, merchantID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrWebhookKeyNotFound
+	}
+	return key, nil
+}
+
+// generateKeyMaterial gera o material secreto da chave e, para HMAC, o segredo a entregar ao
+// comerciante; para Ed25519 o material é a semente e a chave pública fica na chave
+func (s *WebhookKeyService) generateKeyMaterial(key *WebhookSigningKey) ([]byte, string, error) {
+	if key.Algorithm == WebhookKeyAlgorithmEd25519 {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, "", fmt.Errorf("falha ao gerar chave Ed25519: %w", err)
+		}
+		key.PublicKey = webhookPublicKeyPrefix + base64.StdEncoding.EncodeToString(public)
+		return private.Seed(), "", nil
+	}
+
+	secret := make([]byte, webhookHMACSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("falha ao gerar segredo HMAC: %w", err)
+	}
+	return secret, webhookSecretPrefix + base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// seal cifra o material da chave, associado ao seu identificador, com a chave mestra
+func (s *WebhookKeyService) seal(keyID string, material []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("falha ao gerar nonce: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, material, []byte(keyID)), nil
+}
+
+// open decifra o material da chave; falha se o texto cifrado pertencer a outra chave
+func (s *WebhookKeyService) open(key *WebhookSigningKey) ([]byte, error) {
+	nonceSize := s.aead.NonceSize()
+	if len(key.SecretCiphertext) < nonceSize {
+		return nil, fmt.Errorf("falha ao decifrar chave %s: texto cifrado inválido", key.KeyID)
+	}
+	nonce, ciphertext := key.SecretCiphertext[:nonceSize], key.SecretCiphertext[nonceSize:]
+	material, err := s.aead.Open(nil, nonce, ciphertext, []byte(key.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao decifrar chave %s: %w", key.KeyID, err)
+	}
+	return material, nil
+}
+
+// webhookSignedContent monta o conteúdo assinado "<webhook_id>.<timestamp>.<payload>"
+func webhookSignedContent(webhookID, timestamp string, payload []byte) []byte {
+	content := make([]byte, 0, len(webhookID)+len(timestamp)+len(payload)+2)
+	content = append(content, webhookID...)
+	content = append(content, '.')
+	content = append(content, timestamp...)
+	content = append(content, '.')
+	return append(content, payload...)
+}
+
+// signWebhookContent assina o conteúdo com o material da chave e devolve a assinatura em base64
+func signWebhookContent(algorithm string, material, content []byte) string {
+	if algorithm == WebhookKeyAlgorithmEd25519 {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.NewKeyFromSeed(material), content))
+	}
+	mac := hmac.New(sha256.New, material)
+	mac.Write(content)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
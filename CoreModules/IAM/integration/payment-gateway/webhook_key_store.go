@@ -0,0 +1,107 @@
+package paymentgateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// WebhookKeyStore define a persistência das chaves de assinatura dos webhooks
+type WebhookKeyStore interface {
+	// SaveRotation grava a nova chave e as chaves anteriores alteradas pela rotação, atomicamente
+	SaveRotation(ctx context.Context, key *WebhookSigningKey, previous []*WebhookSigningKey) error
+
+	// UpdateKey grava o estado de uma chave existente
+	UpdateKey(ctx context.Context, key *WebhookSigningKey) error
+
+	// GetKey recupera uma chave do comerciante, ou nil se não existir
+	GetKey(ctx context.Context, tenantID, merchantID, keyID string) (*WebhookSigningKey, error)
+
+	// ListKeys lista as chaves do comerciante, da mais recente para a mais antiga
+	ListKeys(ctx context.Context, tenantID, merchantID string) ([]*WebhookSigningKey, error)
+}
+
+// InMemoryWebhookKeyStore armazena as chaves dos webhooks em memória
+type InMemoryWebhookKeyStore struct {
+	keys  map[string]*WebhookSigningKey // Por identificador da chave
+	mutex sync.RWMutex
+}
+
+// NewInMemoryWebhookKeyStore cria um novo armazenamento em memória
+func NewInMemoryWebhookKeyStore() *InMemoryWebhookKeyStore {
+	return &InMemoryWebhookKeyStore{
+		keys: make(map[string]*WebhookSigningKey),
+	}
+}
+
+// SaveRotation grava cópias da nova chave e das chaves anteriores
+func (s *InMemoryWebhookKeyStore) SaveRotation(ctx context.Context, key *WebhookSigningKey, previous []*WebhookSigningKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	replaced := make(map[string]bool, len(previous))
+	for _, prev := range previous {
+		if _, exists := s.keys[prev.KeyID]; !exists {
+			return ErrWebhookKeyNotFound
+		}
+		replaced[prev.KeyID] = true
+	}
+	// Uma única chave ativa por comerciante, como no índice único do PostgreSQL
+	for _, existing := range s.keys {
+		if existing.TenantID == key.TenantID && existing.MerchantID == key.MerchantID &&
+			existing.Status == WebhookKeyStatusActive && !replaced[existing.KeyID] {
+			return ErrWebhookKeyStateInvalid
+		}
+	}
+	for _, prev := range previous {
+		copied := *prev
+		s.keys[prev.KeyID] = &copied
+	}
+	copied := *key
+	s.keys[key.KeyID] = &copied
+	return nil
+}
+
+// UpdateKey grava uma cópia da chave existente
+func (s *InMemoryWebhookKeyStore) UpdateKey(ctx context.Context, key *WebhookSigningKey) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.keys[key.KeyID]; !exists {
+		return ErrWebhookKeyNotFound
+	}
+	copied := *key
+	s.keys[key.KeyID] = &copied
+	return nil
+}
+
+// GetKey recupera uma cópia da chave do comerciante
+func (s *InMemoryWebhookKeyStore) GetKey(ctx context.Context, tenantID, merchantID, keyID string) (*WebhookSigningKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	key, exists := s.keys[keyID]
+	if !exists || key.TenantID != tenantID || key.MerchantID != merchantID {
+		return nil, nil
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// ListKeys lista cópias das chaves do comerciante
+func (s *InMemoryWebhookKeyStore) ListKeys(ctx context.Context, tenantID, merchantID string) ([]*WebhookSigningKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := make([]*WebhookSigningKey, 0)
+	for _, key := range s.keys {
+		if key.TenantID == tenantID && key.MerchantID == merchantID {
+			copied := *key
+			keys = append(keys, &copied)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
@@ -9,9 +9,12 @@ package adapter
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -42,17 +46,38 @@ type HookObservability struct {
 	metricsServer     *http.Server
 	complianceMetadata map[string]ComplianceMetadata
 	mutex             sync.RWMutex
-
-	// Métricas Prometheus
-	hookCallsTotal          *prometheus.CounterVec
-	hookErrorsTotal         *prometheus.CounterVec
-	hookDurationSeconds     *prometheus.HistogramVec
-	hookActiveElevations    *prometheus.GaugeVec
-	mfaValidationTotal      *prometheus.CounterVec
-	scopeValidationTotal    *prometheus.CounterVec
-	testCoveragePct         *prometheus.GaugeVec
-	complianceEventsTotal   *prometheus.CounterVec
-	securityEventsTotal     *prometheus.CounterVec
+	redactor          *PIIRedactor
+	complianceCipher  *ComplianceLogCipher
+	complianceQuarantine ComplianceQuarantine
+	push              PushConfig
+	pusher            MetricsPusher
+
+	// Métricas Prometheus (e OpenTelemetry quando há MeterProvider), com o namespace da instância
+	hookCallsTotal          *metricInstrument
+	hookErrorsTotal         *metricInstrument
+	hookDurationSeconds     *metricInstrument
+	hookActiveElevations    *metricInstrument
+	mfaValidationTotal      *metricInstrument
+	scopeValidationTotal    *metricInstrument
+	testCoveragePct         *metricInstrument
+	complianceEventsTotal   *metricInstrument
+	securityEventsTotal     *metricInstrument
+	paymentMetrics          map[string]*metricInstrument
+	registeredCollectors    []prometheus.Collector
+	metricCallbacks         []metric.Registration
+
+	// Componentes alterados em tempo de execução pelo endpoint administrativo
+	logLevel      zap.AtomicLevel
+	sampler       *runtimeSampler
+	metricsByName map[string]*metricInstrument
+	runtime       runtimeState
+
+	// Avaliação dos SLOs sobre os histogramas da instância (nil sem SLOs configurados)
+	slo *sloTracker
+
+	// Orçamentos de latência das operações de hook (nil sem orçamentos configurados)
+	latencyBudgets            *latencyBudgets
+	hookLatencyBudgetExceeded *metricInstrument
 }
 
 // NewHookObservability cria uma nova instância do adaptador de observabilidade
@@ -69,6 +94,28 @@ func NewHookObservability(config Config) (*HookObservability, error) {
 	h := &HookObservability{
 		config:             config,
 		complianceMetadata: make(map[string]ComplianceMetadata),
+		sampler:            newRuntimeSampler(config.TraceSampleRate),
+		latencyBudgets:     newLatencyBudgets(config.LatencyBudgets),
+	}
+
+	// Configurar redação de PII antes dos demais componentes
+	if config.RedactPII {
+		h.redactor = NewPIIRedactor(config.PIISensitiveFields...)
+	}
+
+	// Configurar cifra por tenant dos logs de compliance
+	if config.EncryptComplianceLogs {
+		provider := config.ComplianceKeyProvider
+		if provider == nil {
+			provider = NewFileKeyProvider(config.ComplianceKeysPath)
+		}
+		h.complianceCipher = NewComplianceLogCipher(provider)
+
+		// Eventos que não podem ser cifrados ficam em quarentena em vez de serem descartados
+		h.complianceQuarantine = config.ComplianceQuarantine
+		if h.complianceQuarantine == nil {
+			h.complianceQuarantine = NewFileComplianceQuarantine(config.complianceQuarantinePath())
+		}
 	}
 
 	// Configurar componentes
@@ -80,7 +127,14 @@ func NewHookObservability(config Config) (*HookObservability, error) {
 		h.logger.Warn("Falha ao configurar tracer, continuando sem tracing", zap.Error(err))
 	}
 
+	h.setupMetricsPush()
+
 	if err := h.setupMetrics(); err != nil {
+		// Conflitos de nomes são erros de configuração: duas instâncias no mesmo destino
+		if errors.Is(err, ErrMetricConflict) {
+			h.Close()
+			return nil, fmt.Errorf("falha ao registar métricas: %w", err)
+		}
 		h.logger.Warn("Falha ao configurar métricas, continuando sem métricas", zap.Error(err))
 	}
 
@@ -88,7 +142,14 @@ func NewHookObservability(config Config) (*HookObservability, error) {
 		zap.String("service", config.ServiceName),
 		zap.String("environment", config.Environment),
 		zap.Bool("metrics_enabled", config.MetricsPort > 0),
-		zap.Bool("tracing_enabled", config.OTLPEndpoint != ""),
+		zap.String("metric_namespace", h.metricNamespace()),
+		zap.Bool("metrics_shared_registry", config.MetricsRegistry != nil),
+		zap.Bool("metrics_otel_enabled", config.MeterProvider != nil),
+		zap.Bool("metrics_push_enabled", h.pusher != nil),
+		zap.Bool("tracing_enabled", config.OTLPEndpoint != "" || config.SpanExporter != nil),
+		zap.Bool("runtime_admin_enabled", config.RuntimeAdmin != nil),
+		zap.Bool("slos_enabled", h.slo != nil),
+		zap.Bool("latency_budgets_enabled", h.latencyBudgets != nil),
 	)
 
 	return h, nil
@@ -98,6 +159,12 @@ func NewHookObservability(config Config) (*HookObservability, error) {
 func (h *HookObservability) Close() error {
 	var errs []error
 
+	// Cancelar a reposição automática pendente
+	h.closeRuntime()
+
+	// Parar a amostragem dos SLOs antes de retirar os histogramas
+	h.closeSLOs()
+
 	// Fechar servidor de métricas se estiver ativo
 	if h.metricsServer != nil {
 		if err := h.metricsServer.Close(); err != nil {
@@ -105,6 +172,11 @@ func (h *HookObservability) Close() error {
 		}
 	}
 
+	// Retirar as métricas do registo e do meter, que podem ser partilhados com outras instâncias
+	if err := h.unregisterMetrics(); err != nil {
+		errs = append(errs, fmt.Errorf("erro ao remover callbacks de métricas: %w", err))
+	}
+
 	// Fechar provedor de traces se estiver ativo
 	if h.tracerProvider != nil {
 		if err := h.tracerProvider.Shutdown(context.Background()); err != nil {
@@ -134,16 +206,9 @@ func (h *HookObservability) setupLogger() error {
 	var cfg zap.Config
 
 	// Definir nível de log
-	level := zap.InfoLevel
-	switch h.config.LogLevel {
-	case "debug":
-		level = zap.DebugLevel
-	case "info":
-		level = zap.InfoLevel
-	case "warn":
-		level = zap.WarnLevel
-	case "error":
-		level = zap.ErrorLevel
+	level, err := parseLogLevel(h.config.LogLevel)
+	if err != nil {
+		return err
 	}
 
 	// Configurar encoding conforme preferência
@@ -158,8 +223,10 @@ func (h *HookObservability) setupLogger() error {
 		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
-	// Configurações comuns
-	cfg.Level = zap.NewAtomicLevelAt(level)
+	// Configurações comuns; o nível é partilhado com o endpoint administrativo, que o altera
+	// sem recriar o logger
+	h.logLevel = zap.NewAtomicLevelAt(level)
+	cfg.Level = h.logLevel
 	cfg.DisableCaller = false
 	cfg.DisableStacktrace = false
 	cfg.InitialFields = map[string]interface{}{
@@ -173,14 +240,19 @@ func (h *HookObservability) setupLogger() error {
 		return fmt.Errorf("erro ao criar logger: %w", err)
 	}
 
+	// Redigir PII em todas as entradas de log quando habilitado
+	if h.redactor != nil {
+		logger = logger.WithOptions(zap.WrapCore(h.redactor.WrapCore))
+	}
+
 	h.logger = logger
 	return nil
 }
 
 // setupTracer configura o tracer OpenTelemetry
 func (h *HookObservability) setupTracer() error {
-	// Se não houver endpoint OTLP configurado, desabilitar tracer
-	if h.config.OTLPEndpoint == "" {
+	// Se não houver endpoint OTLP nem exportador configurado, desabilitar tracer
+	if h.config.OTLPEndpoint == "" && h.config.SpanExporter == nil {
 		h.logger.Info("Endpoint OTLP não configurado, tracing desativado")
 		h.tracer = trace.NewNoopTracerProvider().Tracer("noop")
 		return nil
@@ -198,18 +270,34 @@ func (h *HookObservability) setupTracer() error {
 		return fmt.Errorf("falha ao criar recurso de tracing: %w", err)
 	}
 
-	// Configurar exporter OTLP
-	exporter, err := otlptracegrpc.New(context.Background(),
-		otlptracegrpc.WithEndpoint(h.config.OTLPEndpoint),
-		otlptracegrpc.WithInsecure(), // Remover em produção e usar TLS
-	)
-	if err != nil {
-		return fmt.Errorf("falha ao criar exportador OTLP: %w", err)
+	// Configurar exporter OTLP, salvo quando um exportador é injetado
+	exporter := h.config.SpanExporter
+	if exporter == nil {
+		exporter, err = otlptracegrpc.New(context.Background(),
+			otlptracegrpc.WithEndpoint(h.config.OTLPEndpoint),
+			otlptracegrpc.WithInsecure(), // Remover em produção e usar TLS
+		)
+		if err != nil {
+			return fmt.Errorf("falha ao criar exportador OTLP: %w", err)
+		}
+	}
+
+	// Redigir spans antes da exportação para que PII não saia do processo
+	if h.redactor != nil {
+		redaction := DefaultSpanRedactionConfig()
+		if h.config.SpanRedaction != nil {
+			redaction = *h.config.SpanRedaction
+		}
+		spanRedactor, err := NewSpanRedactor(redaction, h.redactor)
+		if err != nil {
+			return fmt.Errorf("falha ao configurar redação de spans: %w", err)
+		}
+		exporter = spanRedactor.WrapExporter(exporter)
 	}
 
 	// Criar provedor de trace com amostragem configurável
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(h.config.TraceSampleRate)),
+		sdktrace.WithSampler(h.sampler),
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
 	)
@@ -226,117 +314,104 @@ func (h *HookObservability) setupTracer() error {
 
 	return nil
 }// setupMetrics configura métricas Prometheus e inicia servidor HTTP
+// Com envio para o Pushgateway, registo partilhado ou MeterProvider, as métricas são registadas
+// mesmo sem porta configurada
 func (h *HookObservability) setupMetrics() error {
-	// Se a porta de métricas não estiver configurada, desabilitar métricas
-	if h.config.MetricsPort <= 0 {
+	// Se a porta de métricas não estiver configurada nem houver outro destino, desabilitar métricas
+	if h.config.MetricsPort <= 0 && h.pusher == nil && h.config.MetricsRegistry == nil && h.config.MeterProvider == nil {
 		h.logger.Info("Porta de métricas não configurada, métricas desativadas")
 		return nil
 	}
 
-	// Criar registro Prometheus
-	registry := prometheus.NewRegistry()
+	// Usar o registro partilhado indicado ou criar um registro próprio com as métricas padrão,
+	// que num registro partilhado ficam a cargo de quem o criou
+	registry := h.config.MetricsRegistry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+		registry.MustRegister(prometheus.NewGoCollector())
+	}
 	h.metricsRegistry = registry
 
-	// Registrar métricas padrão
-	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
-	registry.MustRegister(prometheus.NewGoCollector())
-
-	// Contador de chamadas de hook
-	h.hookCallsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_hook_calls_total",
-			Help: "Total de chamadas de hook MCP-IAM",
-		},
-		[]string{"market", "tenant_type", "hook_type", "operation"},
-	)
-	registry.MustRegister(h.hookCallsTotal)
-
-	// Contador de erros de hook
-	h.hookErrorsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_hook_errors_total",
-			Help: "Total de erros em chamadas de hook MCP-IAM",
-		},
-		[]string{"market", "tenant_type", "hook_type", "operation"},
-	)
-	registry.MustRegister(h.hookErrorsTotal)
-
-	// Histograma de duração de execução
-	h.hookDurationSeconds = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "innovabiz_iam_hook_duration_seconds",
-			Help:    "Tempo de execução de hooks MCP-IAM em segundos",
-			Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2, 5},
-		},
-		[]string{"market", "tenant_type", "hook_type", "operation"},
-	)
-	registry.MustRegister(h.hookDurationSeconds)
-
-	// Gauge para elevações de privilégio ativas
-	h.hookActiveElevations = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "innovabiz_iam_active_elevations",
-			Help: "Número de elevações de privilégio ativas por mercado e tipo de tenant",
-		},
-		[]string{"market", "tenant_type"},
-	)
-	registry.MustRegister(h.hookActiveElevations)
-
-	// Contador de validações MFA
-	h.mfaValidationTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_mfa_validations_total",
-			Help: "Total de validações MFA por mercado, tenant e resultado",
-		},
-		[]string{"market", "tenant_type", "level", "result"},
-	)
-	registry.MustRegister(h.mfaValidationTotal)
-
-	// Contador de validações de escopo
-	h.scopeValidationTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_scope_validations_total",
-			Help: "Total de validações de escopo por mercado, tenant e resultado",
-		},
-		[]string{"market", "tenant_type", "scope", "result"},
-	)
-	registry.MustRegister(h.scopeValidationTotal)
-
-	// Gauge para cobertura de testes
-	h.testCoveragePct = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "innovabiz_iam_test_coverage_percent",
-			Help: "Percentual de cobertura de testes por tipo de hook",
-		},
-		[]string{"hook_type"},
-	)
-	registry.MustRegister(h.testCoveragePct)
-
-	// Contador de eventos de compliance
-	h.complianceEventsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_compliance_events_total",
-			Help: "Total de eventos de compliance por mercado e framework",
-		},
-		[]string{"market", "framework"},
-	)
-	registry.MustRegister(h.complianceEventsTotal)
-
-	// Contador de eventos de segurança
-	h.securityEventsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "innovabiz_iam_security_events_total",
-			Help: "Total de eventos de segurança por mercado e severidade",
-		},
-		[]string{"market", "severity", "event_type"},
-	)
-	registry.MustRegister(h.securityEventsTotal)
+	// Métricas dos hooks e do gateway de pagamentos, criadas a partir do catálogo para manter os
+	// dashboards sincronizados e prefixadas com o namespace da instância
+	namespace := h.metricNamespace()
+	defs := []MetricDefinition{
+		hookCallsMetric.WithNamespace(namespace),
+		hookErrorsMetric.WithNamespace(namespace),
+		hookDurationMetric.WithNamespace(namespace),
+		activeElevationsMetric.WithNamespace(namespace),
+		mfaValidationsMetric.WithNamespace(namespace),
+		scopeValidationsMetric.WithNamespace(namespace),
+		testCoverageMetric.WithNamespace(namespace),
+		complianceEventsMetric.WithNamespace(namespace),
+		securityEventsMetric.WithNamespace(namespace),
+	}
+	for _, def := range paymentMetrics {
+		defs = append(defs, def.WithNamespace(namespace))
+	}
+	if h.config.SLOs != nil {
+		defs = append(defs, sloMetricDefinitions(namespace)...)
+	}
+	if h.config.LatencyBudgets != nil {
+		defs = append(defs, latencyBudgetMetricDefinitions(namespace)...)
+	}
+	instruments, err := h.registerMetrics(registry, defs)
+	if err != nil {
+		h.metricsRegistry = nil
+		return err
+	}
+
+	h.hookCallsTotal = instruments[defs[0].Name]
+	h.hookErrorsTotal = instruments[defs[1].Name]
+	h.hookDurationSeconds = instruments[defs[2].Name]
+	h.hookActiveElevations = instruments[defs[3].Name]
+	h.mfaValidationTotal = instruments[defs[4].Name]
+	h.scopeValidationTotal = instruments[defs[5].Name]
+	h.testCoveragePct = instruments[defs[6].Name]
+	h.complianceEventsTotal = instruments[defs[7].Name]
+	h.securityEventsTotal = instruments[defs[8].Name]
+
+	// Métricas do gateway de pagamentos, alimentadas por RecordMetric e RecordHistogram
+	h.paymentMetrics = make(map[string]*metricInstrument, len(paymentMetrics))
+	for _, def := range defs[9 : 9+len(paymentMetrics)] {
+		h.paymentMetrics[def.Name] = instruments[def.Name]
+	}
+
+	// Métricas ativadas e desativadas em tempo de execução pelo nome sem o namespace
+	h.metricsByName = make(map[string]*metricInstrument, len(defs))
+	for _, def := range defs {
+		h.metricsByName[strings.TrimPrefix(def.Name, namespace+"_")] = instruments[def.Name]
+	}
 
-	// Iniciar servidor HTTP para expor métricas
-	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	// Contador das operações que excederam o orçamento de latência
+	h.setupLatencyBudgets(instruments)
+
+	// SLOs calculados a partir dos histogramas registados
+	if err := h.setupSLOs(instruments); err != nil {
+		return err
+	}
+
+	// Sem porta de métricas, a exposição fica a cargo do Pushgateway, do dono do registro
+	// partilhado ou do MeterProvider
+	if h.config.MetricsPort <= 0 {
+		h.logger.Info("Métricas registadas sem servidor HTTP")
+		return nil
+	}
+
+	// Iniciar servidor HTTP para expor métricas, com mux próprio para permitir várias instâncias
+	mux := http.NewServeMux()
+	// OpenMetrics expõe os exemplars (trace_id) das observações aos scrapers que o negociam
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	if handler := h.RuntimeAdminHandler(); handler != nil {
+		mux.Handle(h.runtimeAdminPath(), handler)
+	}
+	if handler := h.SLOHandler(); handler != nil {
+		mux.Handle(h.sloPath(), handler)
+	}
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", h.config.MetricsPort),
-		Handler: http.DefaultServeMux,
+		Handler: mux,
 	}
 
 	h.metricsServer = server
@@ -407,12 +482,12 @@ func (h *HookObservability) ObserveHookOperation(
 	startTime := time.Now()
 
 	// Incrementar contador de chamadas
-	h.hookCallsTotal.WithLabelValues(
+	h.hookCallsTotal.record(1,
 		marketCtx.Market,
 		marketCtx.TenantType,
 		marketCtx.HookType,
 		operation,
-	).Inc()
+	)
 
 	// Criar span para a operação
 	ctx, span := h.tracer.Start(ctx, fmt.Sprintf("hook.%s", operation),
@@ -422,13 +497,24 @@ func (h *HookObservability) ObserveHookOperation(
 			attribute.String("hook_type", marketCtx.HookType),
 			attribute.String("operation", operation),
 			attribute.String("user_id", userId),
-			attribute.String("description", description),
+			attribute.String("description", h.redactText(description)),
 		),
 	)
 	// Adicionar atributos extras ao span
-	span.SetAttributes(attrs...)
+	span.SetAttributes(h.redactAttributes(attrs)...)
 	defer span.End()
 
+	// Orçamento de latência da operação, com prazo no contexto quando o orçamento a cancela
+	budget, hasBudget := h.latencyBudgets.lookup(marketCtx.HookType, operation)
+	if hasBudget {
+		span.SetAttributes(attribute.Int64("latency_budget.budget_ms", budget.Budget.Milliseconds()))
+		if budget.Cancel {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, budget.Budget)
+			defer cancel()
+		}
+	}
+
 	// Logger contextualizado
 	logger := h.logger.With(
 		zap.String("market", marketCtx.Market),
@@ -446,24 +532,31 @@ func (h *HookObservability) ObserveHookOperation(
 	// Executar função de operação
 	err := fn(ctx)
 
-	// Registrar tempo de execução
-	duration := time.Since(startTime).Seconds()
-	h.hookDurationSeconds.WithLabelValues(
+	// Registrar tempo de execução, com o trace como exemplar quando o span é amostrado
+	elapsed := time.Since(startTime)
+	duration := elapsed.Seconds()
+	exemplar := traceExemplar(span)
+	h.hookDurationSeconds.recordWithExemplar(duration, exemplar,
 		marketCtx.Market,
 		marketCtx.TenantType,
 		marketCtx.HookType,
 		operation,
-	).Observe(duration)
+	)
+
+	// Avisar quando a operação excede o orçamento de latência
+	if hasBudget && elapsed > budget.Budget {
+		h.recordLatencyBudgetExceeded(ctx, span, exemplar, marketCtx, operation, userId, budget, elapsed)
+	}
 
 	// Registrar resultado
 	if err != nil {
 		// Incrementar contador de erros
-		h.hookErrorsTotal.WithLabelValues(
+		h.hookErrorsTotal.recordWithExemplar(1, exemplar,
 			marketCtx.Market,
 			marketCtx.TenantType,
 			marketCtx.HookType,
 			operation,
-		).Inc()
+		)
 
 		// Registrar erro no span
 		span.SetStatus(codes.Error, err.Error())
@@ -517,12 +610,12 @@ func (h *HookObservability) ObserveValidateScope(
 		result = "failure"
 	}
 
-	h.scopeValidationTotal.WithLabelValues(
+	h.scopeValidationTotal.record(1,
 		marketCtx.Market,
 		marketCtx.TenantType,
 		scope,
 		result,
-	).Inc()
+	)
 
 	return err
 }
@@ -571,12 +664,12 @@ func (h *HookObservability) ObserveValidateMFA(
 		result = "failure"
 	}
 
-	h.mfaValidationTotal.WithLabelValues(
+	h.mfaValidationTotal.record(1,
 		marketCtx.Market,
 		marketCtx.TenantType,
 		mfaLevel,
 		result,
-	).Inc()
+	)
 
 	return err
 }
@@ -597,7 +690,7 @@ func (h *HookObservability) TraceAuditEvent(
 			attribute.String("hook_type", marketCtx.HookType),
 			attribute.String("user_id", userId),
 			attribute.String("event_type", eventType),
-			attribute.String("details", details),
+			attribute.String("details", h.redactText(details)),
 			attribute.String("event_category", "audit"),
 		),
 	)
@@ -615,15 +708,17 @@ func (h *HookObservability) TraceAuditEvent(
 
 	// Registrar log de compliance se habilitado
 	if h.config.EnableComplianceAudit && h.config.ComplianceLogsPath != "" {
-		h.logComplianceEvent(marketCtx.Market, "audit", userId, eventType, details)
+		if err := h.logComplianceEvent(marketCtx, "audit", userId, eventType, details); err != nil {
+			h.recordComplianceFailure(span, marketCtx, "audit", err)
+		}
 	}
 
 	// Se houver metadados de compliance para o mercado, incrementar contador específico
 	if metadata, exists := h.GetComplianceMetadata(marketCtx.Market); exists {
-		h.complianceEventsTotal.WithLabelValues(
+		h.complianceEventsTotal.record(1,
 			marketCtx.Market,
 			metadata.Framework,
-		).Inc()
+		)
 	}
 }// TraceSecurity registra um evento de segurança
 func (h *HookObservability) TraceSecurity(
@@ -642,7 +737,7 @@ func (h *HookObservability) TraceSecurity(
 			attribute.String("hook_type", marketCtx.HookType),
 			attribute.String("user_id", userId),
 			attribute.String("severity", severity),
-			attribute.String("details", details),
+			attribute.String("details", h.redactText(details)),
 			attribute.String("event_type", eventType),
 			attribute.String("event_category", "security"),
 		),
@@ -679,23 +774,25 @@ func (h *HookObservability) TraceSecurity(
 
 	// Registrar log de compliance se habilitado
 	if h.config.EnableComplianceAudit && h.config.ComplianceLogsPath != "" {
-		h.logComplianceEvent(marketCtx.Market, "security", userId, eventType, details)
+		if err := h.logComplianceEvent(marketCtx, "security", userId, eventType, details); err != nil {
+			h.recordComplianceFailure(span, marketCtx, "security", err)
+		}
 	}
 
 	// Incrementar contador de eventos de segurança
-	h.securityEventsTotal.WithLabelValues(
+	h.securityEventsTotal.record(1,
 		marketCtx.Market,
 		severity,
 		eventType,
-	).Inc()
+	)
 }
 
 // UpdateActiveElevations atualiza o número de elevações de privilégio ativas
 func (h *HookObservability) UpdateActiveElevations(marketCtx MarketContext, count float64) {
-	h.hookActiveElevations.WithLabelValues(
+	h.hookActiveElevations.record(count,
 		marketCtx.Market,
 		marketCtx.TenantType,
-	).Set(count)
+	)
 
 	h.logger.Debug("Elevações de privilégio ativas atualizadas",
 		zap.String("market", marketCtx.Market),
@@ -706,7 +803,7 @@ func (h *HookObservability) UpdateActiveElevations(marketCtx MarketContext, coun
 
 // RecordTestCoverage registra a cobertura de testes para um tipo de hook
 func (h *HookObservability) RecordTestCoverage(hookType string, coveragePct float64) {
-	h.testCoveragePct.WithLabelValues(hookType).Set(coveragePct)
+	h.testCoveragePct.record(coveragePct, hookType)
 
 	h.logger.Info("Cobertura de testes registrada",
 		zap.String("hook_type", hookType),
@@ -714,47 +811,139 @@ func (h *HookObservability) RecordTestCoverage(hookType string, coveragePct floa
 	)
 }
 
+// RecordMetric registra um valor numa métrica do gateway de pagamentos pelo nome curto
+// (ex.: payment_gateway_transaction_count). Contadores são incrementados, gauges recebem
+// o valor e histogramas registram uma observação; nomes fora do catálogo são ignorados
+func (h *HookObservability) RecordMetric(marketCtx MarketContext, name, dimension string, value float64) {
+	h.recordPaymentMetric(marketCtx, name, dimension, value)
+}
+
+// RecordHistogram registra uma observação numa métrica do gateway de pagamentos pelo nome curto
+func (h *HookObservability) RecordHistogram(marketCtx MarketContext, name string, value float64, dimension string) {
+	h.recordPaymentMetric(marketCtx, name, dimension, value)
+}
+
+// recordPaymentMetric aplica o valor à métrica registrada com o namespace da instância
+func (h *HookObservability) recordPaymentMetric(marketCtx MarketContext, name, dimension string, value float64) {
+	fullName := h.metricNamespace() + "_" + name
+	instrument, ok := h.paymentMetrics[fullName]
+	if !ok {
+		h.logger.Debug("Métrica não registrada no catálogo, valor ignorado",
+			zap.String("metric", fullName),
+			zap.String("market", marketCtx.Market),
+		)
+		return
+	}
+
+	instrument.record(value, marketCtx.Market, marketCtx.TenantType, dimension)
+}
+
 // logComplianceEvent registra um evento de compliance em arquivo
-func (h *HookObservability) logComplianceEvent(market, eventCategory, userId, eventType, details string) {
+// Com cifra ativa, cada tenant tem diretório próprio e os eventos são gravados cifrados; um evento
+// que não pode ser cifrado é colocado em quarentena. O erro indica que o evento não foi registado
+func (h *HookObservability) logComplianceEvent(marketCtx MarketContext, eventCategory, userId, eventType, details string) error {
+	market := marketCtx.Market
+	tenant := marketCtx.TenantID()
+	if tenant == "" {
+		tenant = DefaultComplianceTenant
+	}
+
 	// Criar diretório específico para o mercado se não existir
 	marketDir := filepath.Join(h.config.ComplianceLogsPath, market)
+	if h.complianceCipher != nil {
+		if err := ValidateComplianceTenant(tenant); err != nil {
+			return fmt.Errorf("%w: %v", ErrComplianceEventLost, err)
+		}
+		marketDir = filepath.Join(marketDir, tenant)
+	}
 	if err := os.MkdirAll(marketDir, 0755); err != nil {
-		h.logger.Error("Falha ao criar diretório de logs de compliance",
-			zap.String("market", market),
-			zap.String("dir", marketDir),
-			zap.Error(err),
-		)
-		return
+		return fmt.Errorf("%w: falha ao criar diretório %s: %v", ErrComplianceEventLost, marketDir, err)
 	}
 
-	// Criar nome do arquivo baseado na data
-	date := time.Now().Format("2006-01-02")
-	fileName := fmt.Sprintf("%s-%s-events.log", date, eventCategory)
-	filePath := filepath.Join(marketDir, fileName)
-
 	// Formatar evento
-	timestamp := time.Now().Format(time.RFC3339)
+	now := time.Now()
 	logLine := fmt.Sprintf("[%s] [%s] [%s] [%s] [%s]: %s\n",
-		timestamp, market, eventCategory, userId, eventType, details)
+		now.Format(time.RFC3339), market, eventCategory, userId, eventType, h.redactText(details))
+
+	// Cifrar o evento com a chave de envelope do tenant
+	if h.complianceCipher != nil {
+		encrypted, err := h.encryptComplianceLine(tenant, logLine)
+		if err != nil {
+			return h.quarantineComplianceEvent(&QuarantinedComplianceEvent{
+				Market:     market,
+				Tenant:     tenant,
+				Category:   eventCategory,
+				Line:       strings.TrimSuffix(logLine, "\n"),
+				Reason:     err.Error(),
+				OccurredAt: now,
+			})
+		}
+		logLine = encrypted
+	}
+
+	// Escrever evento no arquivo do dia
+	filePath := filepath.Join(marketDir, complianceLogFileName(now, eventCategory))
+	if err := appendComplianceLine(filePath, logLine, 0644); err != nil {
+		return fmt.Errorf("%w: %v", ErrComplianceEventLost, err)
+	}
+	return nil
+}
 
-	// Abrir arquivo em modo append
-	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// quarantineComplianceEvent coloca em quarentena um evento que não pôde ser cifrado
+func (h *HookObservability) quarantineComplianceEvent(event *QuarantinedComplianceEvent) error {
+	if err := h.complianceQuarantine.Quarantine(context.Background(), event); err != nil {
+		return fmt.Errorf("%w: falha ao cifrar (%s) e ao colocar em quarentena: %v", ErrComplianceEventLost, event.Reason, err)
+	}
+
+	h.logger.Error("Evento de compliance em quarentena: falha ao cifrar com a chave do tenant",
+		zap.String("market", event.Market),
+		zap.String("tenant", event.Tenant),
+		zap.String("category", event.Category),
+		zap.String("reason", event.Reason),
+	)
+	return nil
+}
+
+// recordComplianceFailure torna visível a perda de um evento de compliance no log e no span
+func (h *HookObservability) recordComplianceFailure(span trace.Span, marketCtx MarketContext, eventCategory string, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	h.logger.Error("Evento de compliance não registado",
+		zap.String("market", marketCtx.Market),
+		zap.String("tenant", marketCtx.TenantID()),
+		zap.String("category", eventCategory),
+		zap.Error(err),
+	)
+}
+
+// encryptComplianceLine cifra uma linha de log de compliance, retornando o registo serializado
+func (h *HookObservability) encryptComplianceLine(tenant, logLine string) (string, error) {
+	record, err := h.complianceCipher.Encrypt(context.Background(), tenant, []byte(strings.TrimSuffix(logLine, "\n")))
 	if err != nil {
-		h.logger.Error("Falha ao abrir arquivo de log de compliance",
-			zap.String("file", filePath),
-			zap.Error(err),
-		)
-		return
+		return "", err
 	}
-	defer f.Close()
 
-	// Escrever evento
-	if _, err := f.WriteString(logLine); err != nil {
-		h.logger.Error("Falha ao escrever evento de compliance",
-			zap.String("file", filePath),
-			zap.Error(err),
-		)
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("falha ao serializar evento cifrado: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// redactText aplica redação de PII a texto livre quando habilitada
+func (h *HookObservability) redactText(s string) string {
+	if h.redactor == nil {
+		return s
+	}
+	return h.redactor.RedactString(s)
+}
+
+// redactAttributes aplica redação de PII a atributos de span quando habilitada
+func (h *HookObservability) redactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if h.redactor == nil {
+		return attrs
 	}
+	return h.redactor.RedactAttributes(attrs)
 }
 
 // isMFALevelSufficient verifica se o nível MFA fornecido atende ao mínimo requerido
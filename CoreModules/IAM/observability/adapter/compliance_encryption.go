@@ -0,0 +1,474 @@
+// Package adapter fornece cifra por tenant dos logs de compliance do adaptador de observabilidade MCP-IAM
+//
+// Este arquivo implementa cifra de envelope: cada evento é cifrado com uma chave de dados (DEK)
+// AES-256-GCM, que por sua vez é cifrada com a chave do tenant (KEK) obtida do provedor de segredos.
+// A rotação da KEK afeta apenas eventos novos; o histórico continua legível com as versões anteriores.
+//
+// Conformidades: ISO/IEC 27001 (A.8.24), PCI-DSS 3.5, LGPD, GDPR, Lei 22/11 (Angola)
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MetadataTenantID é a chave de metadado do MarketContext que identifica o tenant
+	MetadataTenantID = "tenant_id"
+
+	// DefaultComplianceTenant agrupa eventos sem tenant identificado
+	DefaultComplianceTenant = "default"
+
+	// Versão do formato dos registos cifrados
+	complianceRecordVersion = 1
+
+	// Tamanho das chaves AES-256 em bytes
+	complianceKeySize = 32
+
+	// Intervalo de verificação de rotação da chave do tenant
+	complianceKeyCacheTTL = time.Minute
+
+	// Ficheiro do provedor de chaves que indica a versão ativa da chave do tenant
+	currentKeyFileName = "current"
+
+	// Ficheiro do provedor de chaves com os operadores autorizados a ler os logs do tenant
+	operatorsFileName = "operators"
+)
+
+// Erros da cifra de logs de compliance
+var (
+	ErrComplianceKeyNotFound           = errors.New("chave de compliance não encontrada")
+	ErrComplianceOperatorNotAuthorized = errors.New("operador não autorizado a ler logs de compliance do tenant")
+	ErrInvalidComplianceTenant         = errors.New("identificador de tenant inválido")
+	ErrInvalidComplianceRecord         = errors.New("registo de compliance cifrado inválido")
+)
+
+// complianceTenantPattern restringe identificadores de tenant usados em caminhos de ficheiros
+var complianceTenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// ValidateComplianceTenant verifica se o identificador de tenant é seguro para uso em caminhos
+func ValidateComplianceTenant(tenant string) error {
+	if !complianceTenantPattern.MatchString(tenant) || strings.Contains(tenant, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidComplianceTenant, tenant)
+	}
+	return nil
+}
+
+// ComplianceKey representa uma versão da chave de cifra (KEK) de um tenant
+type ComplianceKey struct {
+	Tenant   string
+	Version  string
+	Material []byte
+}
+
+// ComplianceKeyProvider obtém as chaves de cifra dos tenants a partir do provedor de segredos
+type ComplianceKeyProvider interface {
+	// CurrentKey retorna a versão ativa da chave do tenant, usada para cifrar eventos novos
+	CurrentKey(ctx context.Context, tenant string) (*ComplianceKey, error)
+
+	// Key retorna uma versão específica da chave do tenant, usada para decifrar o histórico
+	Key(ctx context.Context, tenant, version string) (*ComplianceKey, error)
+}
+
+// ComplianceLogAuthorizer verifica se um operador pode ler os logs de compliance de um tenant
+type ComplianceLogAuthorizer interface {
+	AuthorizeOperator(ctx context.Context, tenant, operator string) error
+}
+
+// FileKeyProvider é um provedor de chaves baseado em diretório, compatível com segredos
+// montados pelo Vault Agent ou por Secrets do Kubernetes:
+//
+//	<dir>/<tenant>/current      versão ativa da chave
+//	<dir>/<tenant>/<versão>.key material da chave em base64
+//	<dir>/<tenant>/operators    operadores autorizados, um por linha
+type FileKeyProvider struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// NewFileKeyProvider cria um provedor de chaves a partir do diretório indicado
+func NewFileKeyProvider(dir string) *FileKeyProvider {
+	return &FileKeyProvider{dir: dir}
+}
+
+// CurrentKey retorna a versão ativa da chave do tenant
+func (p *FileKeyProvider) CurrentKey(ctx context.Context, tenant string) (*ComplianceKey, error) {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.dir, tenant, currentKeyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: tenant %s", ErrComplianceKeyNotFound, tenant)
+		}
+		return nil, fmt.Errorf("falha ao ler versão ativa da chave do tenant %s: %w", tenant, err)
+	}
+
+	return p.Key(ctx, tenant, strings.TrimSpace(string(data)))
+}
+
+// Key retorna uma versão específica da chave do tenant
+func (p *FileKeyProvider) Key(ctx context.Context, tenant, version string) (*ComplianceKey, error) {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return nil, err
+	}
+	if version == "" || filepath.Base(version) != version {
+		return nil, fmt.Errorf("%w: versão inválida %q", ErrComplianceKeyNotFound, version)
+	}
+
+	data, err := os.ReadFile(filepath.Join(p.dir, tenant, version+".key"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: tenant %s, versão %s", ErrComplianceKeyNotFound, tenant, version)
+		}
+		return nil, fmt.Errorf("falha ao ler chave do tenant %s: %w", tenant, err)
+	}
+
+	material, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(material) != complianceKeySize {
+		return nil, fmt.Errorf("chave inválida para o tenant %s, versão %s", tenant, version)
+	}
+
+	return &ComplianceKey{Tenant: tenant, Version: version, Material: material}, nil
+}
+
+// Rotate gera uma nova versão da chave do tenant e a torna ativa
+// As versões anteriores são mantidas para decifrar o histórico sem recifrá-lo
+func (p *FileKeyProvider) Rotate(ctx context.Context, tenant string) (*ComplianceKey, error) {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	tenantDir := filepath.Join(p.dir, tenant)
+	if err := os.MkdirAll(tenantDir, 0700); err != nil {
+		return nil, fmt.Errorf("falha ao criar diretório de chaves do tenant %s: %w", tenant, err)
+	}
+
+	versions, err := p.Versions(tenant)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if len(versions) > 0 {
+		last, _ := strconv.Atoi(strings.TrimPrefix(versions[len(versions)-1], "v"))
+		next = last + 1
+	}
+
+	material := make([]byte, complianceKeySize)
+	if _, err := io.ReadFull(rand.Reader, material); err != nil {
+		return nil, fmt.Errorf("falha ao gerar chave: %w", err)
+	}
+
+	key := &ComplianceKey{Tenant: tenant, Version: fmt.Sprintf("v%d", next), Material: material}
+	keyPath := filepath.Join(tenantDir, key.Version+".key")
+	encoded := base64.StdEncoding.EncodeToString(material) + "\n"
+	if err := os.WriteFile(keyPath, []byte(encoded), 0600); err != nil {
+		return nil, fmt.Errorf("falha ao gravar chave do tenant %s: %w", tenant, err)
+	}
+
+	// Escrita atómica da versão ativa para não expor leituras parciais aos emissores de logs
+	tmpPath := filepath.Join(tenantDir, currentKeyFileName+".tmp")
+	if err := os.WriteFile(tmpPath, []byte(key.Version+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("falha ao ativar chave do tenant %s: %w", tenant, err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(tenantDir, currentKeyFileName)); err != nil {
+		return nil, fmt.Errorf("falha ao ativar chave do tenant %s: %w", tenant, err)
+	}
+
+	return key, nil
+}
+
+// Versions lista as versões de chave do tenant em ordem crescente
+func (p *FileKeyProvider) Versions(tenant string) ([]string, error) {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(p.dir, tenant))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("falha ao listar chaves do tenant %s: %w", tenant, err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".key") {
+			continue
+		}
+		versions = append(versions, strings.TrimSuffix(name, ".key"))
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(versions[i], "v"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(versions[j], "v"))
+		return a < b
+	})
+	return versions, nil
+}
+
+// AuthorizeOperator verifica se o operador consta da lista de operadores autorizados do tenant
+func (p *FileKeyProvider) AuthorizeOperator(ctx context.Context, tenant, operator string) error {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return err
+	}
+	if operator == "" {
+		return ErrComplianceOperatorNotAuthorized
+	}
+
+	f, err := os.Open(filepath.Join(p.dir, tenant, operatorsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s (tenant %s)", ErrComplianceOperatorNotAuthorized, operator, tenant)
+		}
+		return fmt.Errorf("falha ao ler operadores autorizados do tenant %s: %w", tenant, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == operator {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("falha ao ler operadores autorizados do tenant %s: %w", tenant, err)
+	}
+
+	return fmt.Errorf("%w: %s (tenant %s)", ErrComplianceOperatorNotAuthorized, operator, tenant)
+}
+
+// EncryptedComplianceRecord é a representação em disco de um evento de compliance cifrado
+type EncryptedComplianceRecord struct {
+	Version    int    `json:"v"`
+	Tenant     string `json:"tenant"`
+	KeyVersion string `json:"kid"`
+	WrappedKey string `json:"dek"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ct"`
+}
+
+// ParseComplianceRecord interpreta uma linha de log, indicando se corresponde a um registo cifrado
+// Linhas em texto claro, anteriores à ativação da cifra, retornam false
+func ParseComplianceRecord(line string) (*EncryptedComplianceRecord, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+
+	var record EncryptedComplianceRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil || record.Version == 0 || record.Ciphertext == "" {
+		return nil, false
+	}
+	return &record, true
+}
+
+// tenantDataKey é a chave de dados em uso para cifrar os eventos de um tenant
+type tenantDataKey struct {
+	keyVersion string
+	dek        []byte
+	wrapped    string
+	checkedAt  time.Time
+}
+
+// ComplianceLogCipher cifra e decifra eventos de compliance com chaves de envelope por tenant
+type ComplianceLogCipher struct {
+	provider ComplianceKeyProvider
+	mutex    sync.Mutex
+	dataKeys map[string]*tenantDataKey
+	unwraps  map[string][]byte
+	now      func() time.Time
+}
+
+// NewComplianceLogCipher cria uma cifra de logs de compliance a partir do provedor de chaves
+func NewComplianceLogCipher(provider ComplianceKeyProvider) *ComplianceLogCipher {
+	return &ComplianceLogCipher{
+		provider: provider,
+		dataKeys: make(map[string]*tenantDataKey),
+		unwraps:  make(map[string][]byte),
+		now:      time.Now,
+	}
+}
+
+// Encrypt cifra um evento com a chave de dados do tenant
+func (c *ComplianceLogCipher) Encrypt(ctx context.Context, tenant string, plaintext []byte) (*EncryptedComplianceRecord, error) {
+	dataKey, err := c.dataKey(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, ciphertext, err := sealAESGCM(dataKey.dek, plaintext, complianceAAD(tenant, dataKey.keyVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedComplianceRecord{
+		Version:    complianceRecordVersion,
+		Tenant:     tenant,
+		KeyVersion: dataKey.keyVersion,
+		WrappedKey: dataKey.wrapped,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Decrypt decifra um registo com a versão da chave do tenant usada na cifra
+func (c *ComplianceLogCipher) Decrypt(ctx context.Context, record *EncryptedComplianceRecord) ([]byte, error) {
+	if record.Version != complianceRecordVersion {
+		return nil, fmt.Errorf("%w: versão de formato %d", ErrInvalidComplianceRecord, record.Version)
+	}
+
+	dek, err := c.unwrap(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(record.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce", ErrInvalidComplianceRecord)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(record.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: conteúdo", ErrInvalidComplianceRecord)
+	}
+
+	return openAESGCM(dek, nonce, ciphertext, complianceAAD(record.Tenant, record.KeyVersion))
+}
+
+// dataKey retorna a chave de dados do tenant, gerando uma nova quando a chave do tenant é rodada
+func (c *ComplianceLogCipher) dataKey(ctx context.Context, tenant string) (*tenantDataKey, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	current, exists := c.dataKeys[tenant]
+	if exists && now.Sub(current.checkedAt) < complianceKeyCacheTTL {
+		return current, nil
+	}
+
+	kek, err := c.provider.CurrentKey(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter chave do tenant %s: %w", tenant, err)
+	}
+
+	if exists && current.keyVersion == kek.Version {
+		current.checkedAt = now
+		return current, nil
+	}
+
+	dek := make([]byte, complianceKeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, fmt.Errorf("falha ao gerar chave de dados: %w", err)
+	}
+
+	nonce, wrapped, err := sealAESGCM(kek.Material, dek, complianceAAD(tenant, kek.Version))
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := &tenantDataKey{
+		keyVersion: kek.Version,
+		dek:        dek,
+		wrapped:    base64.StdEncoding.EncodeToString(append(nonce, wrapped...)),
+		checkedAt:  now,
+	}
+	c.dataKeys[tenant] = dataKey
+	c.unwraps[dataKey.wrapped] = dek
+
+	return dataKey, nil
+}
+
+// unwrap decifra a chave de dados de um registo, reutilizando chaves já decifradas
+func (c *ComplianceLogCipher) unwrap(ctx context.Context, record *EncryptedComplianceRecord) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if dek, exists := c.unwraps[record.WrappedKey]; exists {
+		return dek, nil
+	}
+
+	kek, err := c.provider.Key(ctx, record.Tenant, record.KeyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter chave do tenant %s: %w", record.Tenant, err)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(record.WrappedKey)
+	if err != nil || len(wrapped) < 12 {
+		return nil, fmt.Errorf("%w: chave de dados", ErrInvalidComplianceRecord)
+	}
+
+	dek, err := openAESGCM(kek.Material, wrapped[:12], wrapped[12:], complianceAAD(record.Tenant, record.KeyVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	c.unwraps[record.WrappedKey] = dek
+	return dek, nil
+}
+
+// complianceAAD vincula o conteúdo cifrado ao tenant e à versão da chave
+func complianceAAD(tenant, keyVersion string) []byte {
+	return []byte(tenant + "|" + keyVersion)
+}
+
+// sealAESGCM cifra com AES-256-GCM e nonce aleatório
+func sealAESGCM(key, plaintext, aad []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao inicializar cifra: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("falha ao inicializar cifra: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, fmt.Errorf("falha ao gerar nonce: %w", err)
+	}
+
+	return nonce, gcm.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// openAESGCM decifra com AES-256-GCM, validando a autenticidade do conteúdo
+func openAESGCM(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar cifra: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao inicializar cifra: %w", err)
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce", ErrInvalidComplianceRecord)
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: falha de autenticação", ErrInvalidComplianceRecord)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,243 @@
+// Package adapter fornece a quarentena dos eventos de compliance que não puderam ser cifrados
+//
+// Um evento cuja cifra falha (chave do tenant indisponível, provedor de segredos inacessível)
+// não é descartado: é gravado na quarentena, num diretório acessível apenas ao serviço, e
+// reprocessado quando a chave do tenant volta a estar disponível.
+//
+// Conformidades: ISO/IEC 27001 (A.8.15, A.8.24), PCI-DSS 10.5, LGPD, GDPR
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Subdiretório padrão da quarentena dentro do diretório de logs de compliance
+	complianceQuarantineDirName = "quarantine"
+
+	// Extensão dos ficheiros de quarentena, um por tenant
+	complianceQuarantineSuffix = ".jsonl"
+
+	// Sufixo dos ficheiros de eventos de compliance (<data>-<categoria>-events.log)
+	complianceEventsSuffix = "-events.log"
+)
+
+// ErrComplianceEventLost indica que um evento de compliance não foi gravado nos logs nem na quarentena
+var ErrComplianceEventLost = errors.New("evento de compliance não registado")
+
+// QuarantinedComplianceEvent é um evento de compliance que aguarda cifra
+type QuarantinedComplianceEvent struct {
+	Market        string    `json:"market"`
+	Tenant        string    `json:"tenant"`
+	Category      string    `json:"category"`
+	Line          string    `json:"line"` // Linha do evento em texto claro, já com redação de PII
+	Reason        string    `json:"reason"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// ComplianceQuarantine guarda os eventos de compliance que não puderam ser cifrados
+type ComplianceQuarantine interface {
+	Quarantine(ctx context.Context, event *QuarantinedComplianceEvent) error
+}
+
+// FileComplianceQuarantine guarda os eventos em quarentena num ficheiro por tenant:
+//
+//	<dir>/<tenant>.jsonl   um evento JSON por linha
+//
+// O diretório e os ficheiros são criados com acesso restrito ao usuário do serviço,
+// pois os eventos ficam em texto claro até serem reprocessados
+type FileComplianceQuarantine struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// NewFileComplianceQuarantine cria uma quarentena baseada no diretório indicado
+func NewFileComplianceQuarantine(dir string) *FileComplianceQuarantine {
+	return &FileComplianceQuarantine{dir: dir}
+}
+
+// DefaultComplianceQuarantinePath retorna o diretório padrão da quarentena para o diretório de logs
+func DefaultComplianceQuarantinePath(logsPath string) string {
+	return filepath.Join(logsPath, complianceQuarantineDirName)
+}
+
+// Quarantine grava o evento no ficheiro de quarentena do tenant
+func (q *FileComplianceQuarantine) Quarantine(ctx context.Context, event *QuarantinedComplianceEvent) error {
+	if err := ValidateComplianceTenant(event.Tenant); err != nil {
+		return err
+	}
+	if event.QuarantinedAt.IsZero() {
+		event.QuarantinedAt = time.Now().UTC()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = event.QuarantinedAt
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar evento em quarentena: %w", err)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if err := os.MkdirAll(q.dir, 0700); err != nil {
+		return fmt.Errorf("falha ao criar diretório de quarentena: %w", err)
+	}
+	return appendComplianceLine(q.path(event.Tenant), string(data)+"\n", 0600)
+}
+
+// Replay cifra os eventos em quarentena e grava-os nos logs de compliance do tenant.
+// Os eventos cuja cifra volta a falhar permanecem em quarentena
+func (q *FileComplianceQuarantine) Replay(ctx context.Context, logCipher *ComplianceLogCipher, logsPath string) (int, error) {
+	entries, err := os.ReadDir(q.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("falha ao listar quarentena: %w", err)
+	}
+
+	replayed := 0
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), complianceQuarantineSuffix) {
+			continue
+		}
+		tenant := strings.TrimSuffix(entry.Name(), complianceQuarantineSuffix)
+		n, err := q.replayTenant(ctx, logCipher, logsPath, tenant)
+		replayed += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant, err))
+		}
+	}
+	return replayed, errors.Join(errs...)
+}
+
+// replayTenant reprocessa o ficheiro de quarentena de um tenant. O ficheiro é primeiro
+// renomeado, para que os eventos colocados em quarentena entretanto não sejam perdidos
+func (q *FileComplianceQuarantine) replayTenant(ctx context.Context, logCipher *ComplianceLogCipher, logsPath, tenant string) (int, error) {
+	if err := ValidateComplianceTenant(tenant); err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	pending := fmt.Sprintf("%s.replay-%d", q.path(tenant), time.Now().UnixNano())
+	err := os.Rename(q.path(tenant), pending)
+	q.mutex.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("falha ao preparar quarentena para reprocessamento: %w", err)
+	}
+
+	events, err := readQuarantinedEvents(pending)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	var errs []error
+	for _, event := range events {
+		err := replayComplianceEvent(ctx, logCipher, logsPath, event)
+		if err == nil {
+			replayed++
+			continue
+		}
+		event.Reason = err.Error()
+		if qErr := q.Quarantine(ctx, event); qErr != nil {
+			// O ficheiro renomeado é mantido para não perder os eventos por reprocessar
+			return replayed, fmt.Errorf("falha ao devolver evento à quarentena (pendentes em %s): %w", pending, qErr)
+		}
+		errs = append(errs, err)
+	}
+
+	if err := os.Remove(pending); err != nil {
+		errs = append(errs, fmt.Errorf("falha ao remover %s: %w", pending, err))
+	}
+	return replayed, errors.Join(errs...)
+}
+
+// path retorna o ficheiro de quarentena do tenant
+func (q *FileComplianceQuarantine) path(tenant string) string {
+	return filepath.Join(q.dir, tenant+complianceQuarantineSuffix)
+}
+
+// readQuarantinedEvents lê os eventos de um ficheiro de quarentena
+func readQuarantinedEvents(path string) ([]*QuarantinedComplianceEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []*QuarantinedComplianceEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event QuarantinedComplianceEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, fmt.Errorf("evento em quarentena inválido em %s: %w", path, err)
+		}
+		events = append(events, &event)
+	}
+	return events, scanner.Err()
+}
+
+// replayComplianceEvent cifra um evento em quarentena e grava-o no ficheiro do dia em que ocorreu
+func replayComplianceEvent(ctx context.Context, logCipher *ComplianceLogCipher, logsPath string, event *QuarantinedComplianceEvent) error {
+	if err := ValidateComplianceTenant(event.Tenant); err != nil {
+		return err
+	}
+	// O mercado e a categoria compõem o caminho do ficheiro de eventos
+	for _, segment := range []string{event.Market, event.Category} {
+		if segment == "" || segment != filepath.Base(segment) || strings.HasPrefix(segment, ".") {
+			return fmt.Errorf("evento em quarentena com mercado ou categoria inválidos: %q/%q", event.Market, event.Category)
+		}
+	}
+
+	record, err := logCipher.Encrypt(ctx, event.Tenant, []byte(event.Line))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar evento cifrado: %w", err)
+	}
+
+	dir := filepath.Join(logsPath, event.Market, event.Tenant)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("falha ao criar diretório de logs de compliance: %w", err)
+	}
+	return appendComplianceLine(filepath.Join(dir, complianceLogFileName(event.OccurredAt, event.Category)), string(data)+"\n", 0644)
+}
+
+// complianceLogFileName retorna o nome do ficheiro de eventos de uma categoria num dia
+func complianceLogFileName(date time.Time, category string) string {
+	return fmt.Sprintf("%s-%s%s", date.Format("2006-01-02"), category, complianceEventsSuffix)
+}
+
+// appendComplianceLine acrescenta uma linha ao ficheiro, criando-o com as permissões indicadas
+func appendComplianceLine(path, line string, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return fmt.Errorf("falha ao abrir %s: %w", path, err)
+	}
+	if _, err := f.WriteString(line); err != nil {
+		f.Close()
+		return fmt.Errorf("falha ao escrever em %s: %w", path, err)
+	}
+	return f.Close()
+}
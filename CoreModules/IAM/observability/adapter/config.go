@@ -12,6 +12,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // Config define as configurações do adaptador de observabilidade
@@ -42,6 +46,58 @@ type Config struct {
 
 	// Taxa de amostragem para traces (0.0-1.0)
 	TraceSampleRate float64
+
+	// Ativar redação de PII em logs, traces e logs de compliance
+	RedactPII bool
+
+	// Chaves adicionais de campos tratadas como PII (além das padrão)
+	PIISensitiveFields []string
+
+	// Redação de spans antes da exportação, ativa com RedactPII (DefaultSpanRedactionConfig quando nil)
+	SpanRedaction *SpanRedactionConfig
+
+	// Exportador de spans (substitui o exportador OTLP quando definido)
+	SpanExporter sdktrace.SpanExporter `json:"-"`
+
+	// Cifrar logs de compliance por tenant com chaves de envelope
+	EncryptComplianceLogs bool
+
+	// Diretório de chaves dos tenants montado a partir do provedor de segredos
+	ComplianceKeysPath string
+
+	// Provedor de chaves de compliance (substitui ComplianceKeysPath quando definido)
+	ComplianceKeyProvider ComplianceKeyProvider `json:"-"`
+
+	// Diretório de quarentena dos eventos que não puderam ser cifrados (padrão: <ComplianceLogsPath>/quarantine)
+	ComplianceQuarantinePath string
+
+	// Quarentena dos eventos que não puderam ser cifrados (substitui ComplianceQuarantinePath quando definida)
+	ComplianceQuarantine ComplianceQuarantine `json:"-"`
+
+	// Envio das métricas dos jobs em lote para o Pushgateway (nil para desativar)
+	MetricsPush *PushConfig
+
+	// Destino do envio das métricas (substitui o Pushgateway de MetricsPush quando definido)
+	MetricsPusher MetricsPusher `json:"-"`
+
+	// Prefixo dos nomes das métricas (DefaultMetricNamespace quando vazio); módulos no mesmo
+	// processo que partilham um destino precisam de namespaces distintos
+	MetricNamespace string
+
+	// Registro Prometheus partilhado (a instância cria o seu próprio registro quando nil)
+	MetricsRegistry *prometheus.Registry `json:"-"`
+
+	// Provedor de meters OpenTelemetry onde as métricas também são registadas (nil para desativar)
+	MeterProvider metric.MeterProvider `json:"-"`
+
+	// Endpoint administrativo de alteração da configuração em tempo de execução (nil para desativar)
+	RuntimeAdmin *RuntimeAdminConfig
+
+	// SLOs calculados a partir dos histogramas do adaptador (nil para desativar)
+	SLOs *SLOConfig
+
+	// Orçamentos de latência das operações de hook (nil para desativar)
+	LatencyBudgets *LatencyBudgetConfig
 }
 
 // MarketContext encapsula informações de contexto de mercado para observabilidade
@@ -132,6 +188,56 @@ func (c *Config) Validate() error {
 		c.TraceSampleRate = 1.0
 	}
 
+	// Validar redação de spans
+	if c.SpanRedaction != nil {
+		if err := c.SpanRedaction.Validate(); err != nil {
+			return fmt.Errorf("redação de spans inválida: %w", err)
+		}
+	}
+
+	// Validar namespace de métricas se configurado
+	if c.MetricNamespace != "" {
+		if err := ValidateMetricNamespace(c.MetricNamespace); err != nil {
+			return err
+		}
+	}
+
+	// Validar envio de métricas
+	if c.MetricsPush != nil && c.MetricsPusher == nil {
+		if err := c.MetricsPush.Validate(); err != nil {
+			return fmt.Errorf("envio de métricas inválido: %w", err)
+		}
+	}
+
+	// Validar endpoint administrativo
+	if c.RuntimeAdmin != nil {
+		if err := c.RuntimeAdmin.Validate(); err != nil {
+			return fmt.Errorf("endpoint administrativo inválido: %w", err)
+		}
+	}
+
+	// Validar SLOs, que requerem as métricas ativas para amostrar os histogramas
+	if c.SLOs != nil {
+		if err := c.SLOs.Validate(); err != nil {
+			return fmt.Errorf("SLOs inválidos: %w", err)
+		}
+		if c.MetricsPort <= 0 && c.MetricsPush == nil && c.MetricsPusher == nil && c.MetricsRegistry == nil && c.MeterProvider == nil {
+			return fmt.Errorf("SLOs configurados com as métricas desativadas")
+		}
+	}
+
+	// Validar orçamentos de latência
+	if c.LatencyBudgets != nil {
+		if err := c.LatencyBudgets.Validate(); err != nil {
+			return fmt.Errorf("orçamentos de latência inválidos: %w", err)
+		}
+	}
+
+	// Validar provedor de chaves quando a cifra de logs de compliance está ativa
+	if c.EncryptComplianceLogs && c.ComplianceKeyProvider == nil && c.ComplianceKeysPath == "" {
+		return fmt.Errorf("cifra de logs de compliance ativa sem provedor de chaves configurado")
+	}
+
 	return nil
 }
 
@@ -150,6 +256,14 @@ func (c *Config) EnsureDirectories() error {
 	return nil
 }
 
+// complianceQuarantinePath retorna o diretório de quarentena dos eventos de compliance
+func (c *Config) complianceQuarantinePath() string {
+	if c.ComplianceQuarantinePath != "" {
+		return c.ComplianceQuarantinePath
+	}
+	return DefaultComplianceQuarantinePath(c.ComplianceLogsPath)
+}
+
 // WithEnvironment define o ambiente de execução
 func (c *Config) WithEnvironment(env string) *Config {
 	c.Environment = env
@@ -204,6 +318,83 @@ func (c *Config) WithTraceSampleRate(rate float64) *Config {
 	return c
 }
 
+// WithPIIRedaction ativa ou desativa a redação de PII, com chaves sensíveis adicionais
+func (c *Config) WithPIIRedaction(enable bool, sensitiveFields ...string) *Config {
+	c.RedactPII = enable
+	c.PIISensitiveFields = append(c.PIISensitiveFields, sensitiveFields...)
+	return c
+}
+
+// WithSpanRedaction define a lista de atributos permitidos e as regras por mercado aplicadas aos spans
+// A redação de spans só é aplicada com RedactPII ativo
+func (c *Config) WithSpanRedaction(redaction SpanRedactionConfig) *Config {
+	c.SpanRedaction = &redaction
+	return c
+}
+
+// WithSpanExporter define o exportador de spans usado em vez do exportador OTLP
+func (c *Config) WithSpanExporter(exporter sdktrace.SpanExporter) *Config {
+	c.SpanExporter = exporter
+	return c
+}
+
+// WithComplianceEncryption ativa a cifra por tenant dos logs de compliance
+// Sem provedor explícito, as chaves são lidas de ComplianceKeysPath
+func (c *Config) WithComplianceEncryption(keysPath string, provider ComplianceKeyProvider) *Config {
+	c.EncryptComplianceLogs = true
+	c.ComplianceKeysPath = keysPath
+	c.ComplianceKeyProvider = provider
+	return c
+}
+
+// WithMetricsPush ativa o envio das métricas dos jobs em lote para o Pushgateway
+func (c *Config) WithMetricsPush(push PushConfig) *Config {
+	c.MetricsPush = &push
+	return c
+}
+
+// WithMetricsPusher define o destino do envio das métricas usado em vez do Pushgateway
+func (c *Config) WithMetricsPusher(pusher MetricsPusher) *Config {
+	c.MetricsPusher = pusher
+	return c
+}
+
+// WithMetricNamespace define o prefixo dos nomes das métricas da instância
+func (c *Config) WithMetricNamespace(namespace string) *Config {
+	c.MetricNamespace = namespace
+	return c
+}
+
+// WithMetricsRegistry define o registro Prometheus partilhado com outros módulos do processo
+func (c *Config) WithMetricsRegistry(registry *prometheus.Registry) *Config {
+	c.MetricsRegistry = registry
+	return c
+}
+
+// WithMeterProvider define o provedor de meters OpenTelemetry onde as métricas são registadas
+func (c *Config) WithMeterProvider(provider metric.MeterProvider) *Config {
+	c.MeterProvider = provider
+	return c
+}
+
+// WithRuntimeAdmin ativa o endpoint administrativo de alteração da configuração em tempo de execução
+func (c *Config) WithRuntimeAdmin(admin RuntimeAdminConfig) *Config {
+	c.RuntimeAdmin = &admin
+	return c
+}
+
+// WithSLOs ativa a avaliação dos SLOs e as métricas de burn rate
+func (c *Config) WithSLOs(slos SLOConfig) *Config {
+	c.SLOs = &slos
+	return c
+}
+
+// WithLatencyBudgets ativa os orçamentos de latência das operações de hook
+func (c *Config) WithLatencyBudgets(budgets LatencyBudgetConfig) *Config {
+	c.LatencyBudgets = &budgets
+	return c
+}
+
 // NewMarketContext cria um novo contexto de mercado para observabilidade
 func NewMarketContext(market, tenantType, hookType string) MarketContext {
 	return MarketContext{
@@ -220,6 +411,11 @@ func (mc MarketContext) WithMetadata(key, value string) MarketContext {
 	return mc
 }
 
+// TenantID retorna o tenant do contexto de mercado, registado em MetadataTenantID
+func (mc MarketContext) TenantID() string {
+	return mc.Metadata[MetadataTenantID]
+}
+
 // GetMetadata obtém um valor de metadado do contexto de mercado
 func (mc MarketContext) GetMetadata(key string) (string, bool) {
 	value, exists := mc.Metadata[key]
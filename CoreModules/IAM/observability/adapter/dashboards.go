@@ -0,0 +1,455 @@
+// Package adapter - geração de dashboards Grafana e regras de alerta Prometheus
+//
+// Os painéis e as expressões PromQL são derivados do catálogo de métricas (metric_catalog.go),
+// de modo que qualquer métrica adicionada ou renomeada no adaptador se reflete nos artefatos
+// gerados sem edição manual. Cada artefato é parametrizado por mercado.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Valores padrão da geração de dashboards
+const (
+	DefaultDashboardDatasource = "prometheus"
+	DefaultDashboardRefresh    = "30s"
+
+	dashboardUIDPrefix = "innovabiz-iam-obs-"
+	rateWindow         = "5m"
+)
+
+// groupTitles define a ordem e o título das secções dos dashboards
+var groupTitles = []struct {
+	Group string
+	Title string
+}{
+	{MetricGroupHooks, "Hooks MCP-IAM"},
+	{MetricGroupElevations, "Elevações de Privilégio"},
+	{MetricGroupValidation, "Validações MFA e Escopo"},
+	{MetricGroupCompliance, "Compliance e Segurança"},
+	{MetricGroupPayments, "Gateway de Pagamentos"},
+	{MetricGroupBatch, "Jobs em Lote"},
+	{MetricGroupSLO, "Objetivos de Nível de Serviço"},
+}
+
+// DashboardOptions parametriza a geração de um dashboard
+type DashboardOptions struct {
+	Market     string // Mercado filtrado pelos painéis (obrigatório)
+	Datasource string // UID da fonte de dados Prometheus no Grafana
+	Title      string // Título do dashboard (padrão: derivado do mercado)
+	Refresh    string // Intervalo de atualização (padrão: 30s)
+	Namespace  string // Namespace das métricas da instância (padrão: DefaultMetricNamespace)
+}
+
+// GenerateGrafanaDashboard gera o JSON de um dashboard Grafana com um painel por métrica
+// do catálogo, filtrado pelo mercado indicado
+func GenerateGrafanaDashboard(opts DashboardOptions) ([]byte, error) {
+	if opts.Market == "" {
+		return nil, fmt.Errorf("mercado é obrigatório para gerar o dashboard")
+	}
+	if opts.Datasource == "" {
+		opts.Datasource = DefaultDashboardDatasource
+	}
+	if opts.Title == "" {
+		opts.Title = fmt.Sprintf("INNOVABIZ IAM - Observabilidade (%s)", opts.Market)
+	}
+	if opts.Refresh == "" {
+		opts.Refresh = DefaultDashboardRefresh
+	}
+
+	datasource := map[string]interface{}{"type": "prometheus", "uid": "${datasource}"}
+	catalog := MetricCatalogWithNamespace(opts.Namespace)
+
+	var panels []map[string]interface{}
+	panelID := 1
+	y := 0
+	for _, group := range groupTitles {
+		var defs []MetricDefinition
+		for _, def := range catalog {
+			if def.Group == group.Group {
+				defs = append(defs, def)
+			}
+		}
+		if len(defs) == 0 {
+			continue
+		}
+
+		panels = append(panels, map[string]interface{}{
+			"id":        panelID,
+			"type":      "row",
+			"title":     group.Title,
+			"collapsed": false,
+			"gridPos":   map[string]int{"h": 1, "w": 24, "x": 0, "y": y},
+			"panels":    []interface{}{},
+		})
+		panelID++
+		y++
+
+		for i, def := range defs {
+			targets := make([]map[string]interface{}, 0, 3)
+			for j, target := range panelQueries(def, opts.Market) {
+				targets = append(targets, map[string]interface{}{
+					"refId":        string(rune('A' + j)),
+					"datasource":   datasource,
+					"expr":         target.Expr,
+					"legendFormat": target.Legend,
+				})
+			}
+
+			panels = append(panels, map[string]interface{}{
+				"id":          panelID,
+				"type":        "timeseries",
+				"title":       def.Title,
+				"description": fmt.Sprintf("%s (%s)", def.Help, def.Name),
+				"datasource":  datasource,
+				"gridPos":     map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": y + (i/2)*8},
+				"fieldConfig": map[string]interface{}{
+					"defaults":  map[string]interface{}{"unit": def.Unit},
+					"overrides": []interface{}{},
+				},
+				"targets": targets,
+			})
+			panelID++
+		}
+		y += ((len(defs) + 1) / 2) * 8
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           dashboardUIDPrefix + marketSlug(opts.Market),
+		"title":         opts.Title,
+		"tags":          []string{"innovabiz", "iam", "mcp-iam", marketSlug(opts.Market)},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"version":       1,
+		"editable":      true,
+		"refresh":       opts.Refresh,
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{
+					"name":    "datasource",
+					"label":   "Fonte de dados",
+					"type":    "datasource",
+					"query":   "prometheus",
+					"current": map[string]string{"text": opts.Datasource, "value": opts.Datasource},
+				},
+				{
+					"name":       labelTenantType,
+					"label":      "Tipo de tenant",
+					"type":       "query",
+					"datasource": datasource,
+					"query":      fmt.Sprintf("label_values(%s{%s=%q}, %s)", hookCallsMetric.WithNamespace(opts.Namespace).Name, labelMarket, opts.Market, labelTenantType),
+					"refresh":    2,
+					"multi":      true,
+					"includeAll": true,
+					"allValue":   ".*",
+					"current":    map[string]interface{}{"text": "All", "value": []string{"$__all"}},
+				},
+			},
+		},
+		"panels": panels,
+	}
+
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// panelQuery é uma consulta PromQL de um painel
+type panelQuery struct {
+	Expr   string
+	Legend string
+}
+
+// panelQueries deriva as consultas de um painel a partir do tipo e dos rótulos da métrica:
+// taxas para contadores, somas para gauges e percentis para histogramas
+func panelQueries(def MetricDefinition, market string) []panelQuery {
+	selector := metricSelector(def, market)
+	by := breakdownLabels(def)
+	legend := legendFormat(by)
+
+	switch def.Type {
+	case MetricTypeCounter:
+		return []panelQuery{{
+			Expr:   fmt.Sprintf("sum by (%s) (rate(%s%s[%s]))", strings.Join(by, ", "), def.Name, selector, rateWindow),
+			Legend: legend,
+		}}
+	case MetricTypeGauge:
+		return []panelQuery{{
+			Expr:   fmt.Sprintf("sum by (%s) (%s%s)", strings.Join(by, ", "), def.Name, selector),
+			Legend: legend,
+		}}
+	default:
+		queries := make([]panelQuery, 0, 3)
+		for _, q := range []string{"0.5", "0.95", "0.99"} {
+			queries = append(queries, panelQuery{
+				Expr: fmt.Sprintf("histogram_quantile(%s, sum by (le, %s) (rate(%s_bucket%s[%s])))",
+					q, strings.Join(by, ", "), def.Name, selector, rateWindow),
+				Legend: fmt.Sprintf("p%s %s", strings.TrimPrefix(q, "0."), legend),
+			})
+		}
+		return queries
+	}
+}
+
+// metricSelector monta o seletor de rótulos com o filtro de mercado e a variável de tenant,
+// apenas para os rótulos que a métrica possui
+func metricSelector(def MetricDefinition, market string) string {
+	var matchers []string
+	if def.HasLabel(labelMarket) {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", labelMarket, market))
+	}
+	if def.HasLabel(labelTenantType) {
+		matchers = append(matchers, fmt.Sprintf("%s=~\"$%s\"", labelTenantType, labelTenantType))
+	}
+	if len(matchers) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(matchers, ", ") + "}"
+}
+
+// breakdownLabels retorna os rótulos da métrica usados na desagregação dos painéis
+// (todos exceto o mercado, que é fixo em cada dashboard)
+func breakdownLabels(def MetricDefinition) []string {
+	labels := make([]string, 0, len(def.Labels))
+	for _, l := range def.Labels {
+		if l != labelMarket {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// legendFormat monta a legenda Grafana a partir dos rótulos de desagregação
+func legendFormat(labels []string) string {
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, "{{"+l+"}}")
+	}
+	return strings.Join(parts, " ")
+}
+
+// marketSlug normaliza o nome do mercado para uso em UIDs, etiquetas e nomes de arquivo
+func marketSlug(market string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(market), " ", "-"))
+}
+
+// AlertThresholds define os limiares das regras de alerta geradas
+type AlertThresholds struct {
+	HookErrorRatio          float64 // Proporção de chamadas de hook com erro
+	HookLatencyP95Seconds   float64 // Percentil 95 da duração dos hooks
+	MaxActiveElevations     float64 // Elevações de privilégio ativas por tipo de tenant
+	MFAFailureRatio         float64 // Proporção de validações MFA falhadas
+	PaymentRiskScoreP95     float64 // Percentil 95 da pontuação de risco das transações
+	ComplianceRuleLatencyMs float64 // Percentil 95 da duração das regras de compliance
+	BatchJobMaxAgeSeconds   float64 // Tempo máximo desde o último sucesso de um job em lote
+	For                     string  // Duração mínima da condição antes de disparar
+}
+
+// DefaultAlertThresholds retorna os limiares padrão das regras de alerta
+func DefaultAlertThresholds() AlertThresholds {
+	return AlertThresholds{
+		HookErrorRatio:          0.05,
+		HookLatencyP95Seconds:   1,
+		MaxActiveElevations:     20,
+		MFAFailureRatio:         0.2,
+		PaymentRiskScoreP95:     80,
+		ComplianceRuleLatencyMs: 250,
+		BatchJobMaxAgeSeconds:   26 * 3600,
+		For:                     "10m",
+	}
+}
+
+// PrometheusRuleFile é o formato de arquivo de regras do Prometheus
+type PrometheusRuleFile struct {
+	Groups []PrometheusRuleGroup `yaml:"groups"`
+}
+
+// PrometheusRuleGroup agrupa regras avaliadas em conjunto
+type PrometheusRuleGroup struct {
+	Name  string           `yaml:"name"`
+	Rules []PrometheusRule `yaml:"rules"`
+}
+
+// PrometheusRule é uma regra de alerta do Prometheus
+type PrometheusRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	metrics []string // Métricas do catálogo referenciadas pela expressão
+}
+
+// BuildPrometheusRules monta as regras de alerta de um mercado a partir do catálogo de métricas
+func BuildPrometheusRules(market string, thresholds AlertThresholds) (PrometheusRuleFile, error) {
+	if market == "" {
+		return PrometheusRuleFile{}, fmt.Errorf("mercado é obrigatório para gerar regras de alerta")
+	}
+	if thresholds.For == "" {
+		thresholds.For = DefaultAlertThresholds().For
+	}
+
+	m := fmt.Sprintf("%s=%q", labelMarket, market)
+	labels := func(severity string) map[string]string {
+		return map[string]string{"severity": severity, labelMarket: market, "service": "innovabiz-iam"}
+	}
+
+	rules := []PrometheusRule{
+		{
+			Alert: "IAMHookErrorRatioHigh",
+			Expr: fmt.Sprintf("sum by (tenant_type, hook_type) (rate(%s{%s}[%s])) / sum by (tenant_type, hook_type) (rate(%s{%s}[%s])) > %g",
+				MetricHookErrorsTotal, m, rateWindow, MetricHookCallsTotal, m, rateWindow, thresholds.HookErrorRatio),
+			For:    thresholds.For,
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Taxa de erro elevada nos hooks %s ({{ $labels.hook_type }})", market),
+				"description": fmt.Sprintf("Mais de %g%% das chamadas do hook {{ $labels.hook_type }} para tenants {{ $labels.tenant_type }} falharam.", thresholds.HookErrorRatio*100),
+			},
+			metrics: []string{MetricHookErrorsTotal, MetricHookCallsTotal},
+		},
+		{
+			Alert: "IAMHookLatencyHigh",
+			Expr: fmt.Sprintf("histogram_quantile(0.95, sum by (le, tenant_type, hook_type) (rate(%s_bucket{%s}[%s]))) > %g",
+				MetricHookDurationSeconds, m, rateWindow, thresholds.HookLatencyP95Seconds),
+			For:    thresholds.For,
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Latência elevada nos hooks %s ({{ $labels.hook_type }})", market),
+				"description": fmt.Sprintf("O percentil 95 da duração do hook {{ $labels.hook_type }} excede %gs.", thresholds.HookLatencyP95Seconds),
+			},
+			metrics: []string{MetricHookDurationSeconds},
+		},
+		{
+			Alert: "IAMHookLatencyBudgetExceeded",
+			Expr: fmt.Sprintf("sum by (tenant_type, hook_type, operation) (rate(%s{%s}[%s])) > 0",
+				MetricHookLatencyBudgetTotal, m, rateWindow),
+			For:    thresholds.For,
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Operação {{ $labels.operation }} acima do orçamento de latência em %s", market),
+				"description": "Chamadas da operação {{ $labels.operation }} do hook {{ $labels.hook_type }} para tenants {{ $labels.tenant_type }} excedem o orçamento de latência configurado; os exemplars ligam aos traces das chamadas lentas.",
+			},
+			metrics: []string{MetricHookLatencyBudgetTotal},
+		},
+		{
+			Alert:  "IAMActiveElevationsHigh",
+			Expr:   fmt.Sprintf("sum by (tenant_type) (%s{%s}) > %g", MetricActiveElevations, m, thresholds.MaxActiveElevations),
+			For:    thresholds.For,
+			Labels: labels("critical"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Número elevado de elevações de privilégio ativas em %s", market),
+				"description": fmt.Sprintf("Mais de %g elevações de privilégio ativas para tenants {{ $labels.tenant_type }}.", thresholds.MaxActiveElevations),
+			},
+			metrics: []string{MetricActiveElevations},
+		},
+		{
+			Alert: "IAMMFAFailureRatioHigh",
+			Expr: fmt.Sprintf("sum by (tenant_type) (rate(%s{%s, result=\"failure\"}[%s])) / sum by (tenant_type) (rate(%s{%s}[%s])) > %g",
+				MetricMFAValidationsTotal, m, rateWindow, MetricMFAValidationsTotal, m, rateWindow, thresholds.MFAFailureRatio),
+			For:    thresholds.For,
+			Labels: labels("critical"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Falhas de MFA acima do normal em %s", market),
+				"description": fmt.Sprintf("Mais de %g%% das validações MFA de tenants {{ $labels.tenant_type }} falharam.", thresholds.MFAFailureRatio*100),
+			},
+			metrics: []string{MetricMFAValidationsTotal},
+		},
+		{
+			Alert: "IAMPaymentRiskScoreHigh",
+			Expr: fmt.Sprintf("histogram_quantile(0.95, sum by (le, tenant_type) (rate(%s_bucket{%s}[%s]))) > %g",
+				MetricPaymentRiskScore, m, rateWindow, thresholds.PaymentRiskScoreP95),
+			For:    thresholds.For,
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Pontuação de risco das transações elevada em %s", market),
+				"description": fmt.Sprintf("O percentil 95 da pontuação de risco das transações excede %g.", thresholds.PaymentRiskScoreP95),
+			},
+			metrics: []string{MetricPaymentRiskScore},
+		},
+		{
+			Alert: "IAMComplianceRuleLatencyHigh",
+			Expr: fmt.Sprintf("histogram_quantile(0.95, sum by (le, dimension) (rate(%s_bucket{%s}[%s]))) > %g",
+				MetricPaymentRuleDurationMs, m, rateWindow, thresholds.ComplianceRuleLatencyMs),
+			For:    thresholds.For,
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Regra de compliance lenta em %s ({{ $labels.dimension }})", market),
+				"description": fmt.Sprintf("O percentil 95 da avaliação da regra {{ $labels.dimension }} excede %gms.", thresholds.ComplianceRuleLatencyMs),
+			},
+			metrics: []string{MetricPaymentRuleDurationMs},
+		},
+		{
+			Alert:  "IAMBatchJobFailed",
+			Expr:   fmt.Sprintf("max by (tenant_type, batch_job) (%s{%s}) == 0", MetricBatchJobSuccess, m),
+			Labels: labels("warning"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Job em lote falhado em %s ({{ $labels.batch_job }})", market),
+				"description": "A última execução do job {{ $labels.batch_job }} para tenants {{ $labels.tenant_type }} terminou com falha.",
+			},
+			metrics: []string{MetricBatchJobSuccess},
+		},
+		{
+			Alert: "IAMBatchJobStale",
+			Expr: fmt.Sprintf("time() - max by (tenant_type, batch_job) (%s{%s}) > %g",
+				MetricBatchJobLastSuccess, m, thresholds.BatchJobMaxAgeSeconds),
+			Labels: labels("critical"),
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Job em lote sem sucesso recente em %s ({{ $labels.batch_job }})", market),
+				"description": fmt.Sprintf("O job {{ $labels.batch_job }} não conclui com sucesso há mais de %gs.", thresholds.BatchJobMaxAgeSeconds),
+			},
+			metrics: []string{MetricBatchJobLastSuccess},
+		},
+		{
+			// A severidade vem da série: o adaptador já combina as janelas longa e curta de cada alerta
+			Alert:  "IAMSLOErrorBudgetBurn",
+			Expr:   fmt.Sprintf("max by (slo, severity) (%s{%s}) == 1", MetricSLOAlertFiring, m),
+			Labels: map[string]string{labelMarket: market, "service": "innovabiz-iam"},
+			Annotations: map[string]string{
+				"summary":     fmt.Sprintf("Orçamento de erro do SLO {{ $labels.slo }} a ser consumido depressa em %s", market),
+				"description": "O burn rate do SLO {{ $labels.slo }} excede o limiar do alerta {{ $labels.severity }} nas janelas longa e curta; os exemplars do histograma ligam aos traces dos eventos lentos.",
+			},
+			metrics: []string{MetricSLOAlertFiring},
+		},
+	}
+
+	// Garantir que as regras só referenciam métricas efetivamente registradas
+	for _, rule := range rules {
+		for _, name := range rule.metrics {
+			if _, ok := LookupMetric(name); !ok {
+				return PrometheusRuleFile{}, fmt.Errorf("regra %s referencia métrica não registrada: %s", rule.Alert, name)
+			}
+		}
+	}
+
+	return PrometheusRuleFile{
+		Groups: []PrometheusRuleGroup{{
+			Name:  fmt.Sprintf("innovabiz-iam-%s", marketSlug(market)),
+			Rules: rules,
+		}},
+	}, nil
+}
+
+// GeneratePrometheusRules gera o arquivo YAML de regras de alerta de um mercado
+func GeneratePrometheusRules(market string, thresholds AlertThresholds) ([]byte, error) {
+	rules, err := BuildPrometheusRules(market, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(rules)
+}
+
+// DashboardFileName retorna o nome de arquivo padrão do dashboard de um mercado
+func DashboardFileName(market string) string {
+	return fmt.Sprintf("grafana-dashboard-%s.json", marketSlug(market))
+}
+
+// RulesFileName retorna o nome de arquivo padrão das regras de alerta de um mercado
+func RulesFileName(market string) string {
+	return fmt.Sprintf("prometheus-rules-%s.yaml", marketSlug(market))
+}
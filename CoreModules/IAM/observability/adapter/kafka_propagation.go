@@ -0,0 +1,111 @@
+// Package adapter - propagação do contexto de trace W3C e do baggage nas mensagens Kafka
+//
+// Os eventos publicados no Kafka (auditoria, alterações de funções) atravessam serviços sem o
+// transporte HTTP que propaga o contexto de trace. O produtor injeta os cabeçalhos traceparent,
+// tracestate e baggage em cada mensagem com InjectKafkaHeaders; o consumidor extrai-os com
+// ExtractKafkaHeaders ou inicia diretamente o span de processamento com StartKafkaConsumerSpan,
+// ligando o trace do consumidor ao do produtor.
+//
+// A propagação usa sempre o formato W3C, independentemente do propagador global, para que
+// serviços sem tracing configurado não quebrem a cadeia.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Cabeçalhos W3C propagados nas mensagens Kafka
+const (
+	KafkaHeaderTraceparent = "traceparent"
+	KafkaHeaderTracestate  = "tracestate"
+	KafkaHeaderBaggage     = "baggage"
+)
+
+// KafkaTracerName é o nome do tracer dos spans de consumo de mensagens Kafka
+const KafkaTracerName = "innovabiz.iam.messaging"
+
+// kafkaPropagator propaga o contexto de trace e o baggage nos cabeçalhos das mensagens Kafka
+var kafkaPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// KafkaHeaderCarrier adapta os cabeçalhos de uma mensagem Kafka a propagation.TextMapCarrier
+// As chaves são comparadas sem distinção de maiúsculas; Set substitui todas as ocorrências da
+// chave, evitando que uma mensagem reencaminhada transporte o contexto do produtor anterior
+type KafkaHeaderCarrier struct {
+	headers *[]kafka.Header
+}
+
+var _ propagation.TextMapCarrier = KafkaHeaderCarrier{}
+
+// NewKafkaHeaderCarrier cria um carrier sobre os cabeçalhos indicados; Set atualiza o slice apontado
+func NewKafkaHeaderCarrier(headers *[]kafka.Header) KafkaHeaderCarrier {
+	return KafkaHeaderCarrier{headers: headers}
+}
+
+// Get retorna o valor da primeira ocorrência da chave ou "" se não existir
+func (c KafkaHeaderCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set define o valor da chave, removendo as ocorrências anteriores
+// Os cabeçalhos são copiados antes da remoção, sem alterar a mensagem de onde provêm
+func (c KafkaHeaderCarrier) Set(key, value string) {
+	headers := make([]kafka.Header, 0, len(*c.headers)+1)
+	for _, header := range *c.headers {
+		if !strings.EqualFold(header.Key, key) {
+			headers = append(headers, header)
+		}
+	}
+	*c.headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys retorna as chaves dos cabeçalhos da mensagem
+func (c KafkaHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
+
+// InjectKafkaHeaders acrescenta aos cabeçalhos o contexto de trace e o baggage de ctx
+// Deve ser chamado com o contexto do span de publicação, para que o consumidor seja seu filho
+func InjectKafkaHeaders(ctx context.Context, headers []kafka.Header) []kafka.Header {
+	kafkaPropagator.Inject(ctx, NewKafkaHeaderCarrier(&headers))
+	return headers
+}
+
+// ExtractKafkaHeaders retorna ctx com o contexto de trace remoto e o baggage dos cabeçalhos
+// Sem cabeçalhos de propagação, ctx é retornado sem alterações
+func ExtractKafkaHeaders(ctx context.Context, headers []kafka.Header) context.Context {
+	return kafkaPropagator.Extract(ctx, NewKafkaHeaderCarrier(&headers))
+}
+
+// StartKafkaConsumerSpan extrai o contexto propagado na mensagem e inicia o span de
+// processamento como filho do span de publicação; o chamador encerra o span
+func StartKafkaConsumerSpan(ctx context.Context, msg kafka.Message) (context.Context, trace.Span) {
+	ctx = ExtractKafkaHeaders(ctx, msg.Headers)
+	return otel.Tracer(KafkaTracerName).Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination", msg.Topic),
+			attribute.Int("messaging.kafka.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		),
+	)
+}
@@ -0,0 +1,213 @@
+// Package adapter - orçamentos de latência por operação de hook
+//
+// Um serviço declara quanto tempo cada operação de hook pode demorar (ex.: validate_scope abaixo de
+// 50ms), para todos os tipos de hook ou para um tipo específico. Quando uma operação observada por
+// ObserveHookOperation excede o seu orçamento, o adaptador incrementa uma métrica dedicada com o
+// trace como exemplar e emite um evento de segurança/operação, dando às equipas de plataforma um
+// aviso antecipado da latência introduzida pelo IAM antes de os SLOs serem afetados. Opcionalmente,
+// o contexto da operação é cancelado ao atingir o orçamento, para que as chamadas dependentes
+// desistam em vez de prolongar o pedido.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valores padrão dos orçamentos de latência
+const (
+	// Severidade do evento emitido quando uma operação excede o orçamento
+	DefaultLatencyBudgetSeverity = constants.SeverityMedium
+
+	// Tipo do evento de segurança emitido quando uma operação excede o orçamento
+	LatencyBudgetExceededEvent = "latency_budget_exceeded"
+)
+
+// ErrLatencyBudgetNotConfigured indica que a configuração não declara orçamentos de latência
+var ErrLatencyBudgetNotConfigured = errors.New("nenhum orçamento de latência configurado")
+
+// LatencyBudgetConfig define os orçamentos de latência das operações de hook
+type LatencyBudgetConfig struct {
+	// Orçamentos declarados pelo serviço
+	Budgets []LatencyBudget
+
+	// Severidade do evento emitido ao exceder um orçamento (padrão: medium)
+	Severity string
+}
+
+// LatencyBudget é a duração máxima de uma operação de hook; o orçamento de um tipo de hook
+// prevalece sobre o orçamento da operação para todos os tipos
+type LatencyBudget struct {
+	// Operação do hook (ex: constants.OperationValidateScope)
+	Operation string
+
+	// Tipo de hook a que o orçamento se aplica (vazio para todos os tipos)
+	HookType string
+
+	// Duração máxima da operação
+	Budget time.Duration
+
+	// Cancelar o contexto da operação quando o orçamento é atingido
+	Cancel bool
+}
+
+// HookLatencyBudget declara o orçamento de latência de uma operação para todos os tipos de hook
+// (ex: HookLatencyBudget(constants.OperationValidateScope, 50*time.Millisecond))
+func HookLatencyBudget(operation string, budget time.Duration) LatencyBudget {
+	return LatencyBudget{Operation: operation, Budget: budget}
+}
+
+// WithHookType restringe o orçamento a um tipo de hook
+func (b LatencyBudget) WithHookType(hookType string) LatencyBudget {
+	b.HookType = hookType
+	return b
+}
+
+// WithCancel cancela o contexto da operação quando o orçamento é atingido
+func (b LatencyBudget) WithCancel() LatencyBudget {
+	b.Cancel = true
+	return b
+}
+
+// Validate valida a configuração dos orçamentos de latência
+func (c LatencyBudgetConfig) Validate() error {
+	if len(c.Budgets) == 0 {
+		return ErrLatencyBudgetNotConfigured
+	}
+	seen := make(map[string]bool, len(c.Budgets))
+	for _, budget := range c.Budgets {
+		if err := budget.Validate(); err != nil {
+			return err
+		}
+		key := latencyBudgetKey(budget.HookType, budget.Operation)
+		if seen[key] {
+			return fmt.Errorf("orçamento de latência duplicado: %s", budget)
+		}
+		seen[key] = true
+	}
+	switch c.Severity {
+	case "", constants.SeverityInfo, constants.SeverityLow, constants.SeverityMedium, constants.SeverityHigh, constants.SeverityCritical:
+	default:
+		return fmt.Errorf("severidade dos orçamentos de latência inválida: %s", c.Severity)
+	}
+	return nil
+}
+
+// Validate valida o orçamento de latência
+func (b LatencyBudget) Validate() error {
+	if strings.TrimSpace(b.Operation) == "" {
+		return fmt.Errorf("orçamento de latência sem operação")
+	}
+	if b.Budget <= 0 {
+		return fmt.Errorf("orçamento de latência de %s inválido: %s", b, b.Budget)
+	}
+	return nil
+}
+
+// String identifica o orçamento pela operação e, quando definido, pelo tipo de hook
+func (b LatencyBudget) String() string {
+	if b.HookType == "" {
+		return b.Operation
+	}
+	return fmt.Sprintf("%s/%s", b.HookType, b.Operation)
+}
+
+// latencyBudgetKey identifica um orçamento pelo tipo de hook e pela operação
+func latencyBudgetKey(hookType, operation string) string {
+	return hookType + "|" + operation
+}
+
+// latencyBudgets indexa os orçamentos pelo tipo de hook e pela operação
+type latencyBudgets struct {
+	budgets  map[string]LatencyBudget
+	severity string
+}
+
+// newLatencyBudgets cria o índice dos orçamentos configurados; retorna nil sem orçamentos
+func newLatencyBudgets(config *LatencyBudgetConfig) *latencyBudgets {
+	if config == nil {
+		return nil
+	}
+	index := &latencyBudgets{
+		budgets:  make(map[string]LatencyBudget, len(config.Budgets)),
+		severity: config.Severity,
+	}
+	if index.severity == "" {
+		index.severity = DefaultLatencyBudgetSeverity
+	}
+	for _, budget := range config.Budgets {
+		index.budgets[latencyBudgetKey(budget.HookType, budget.Operation)] = budget
+	}
+	return index
+}
+
+// lookup retorna o orçamento do tipo de hook e da operação, recorrendo ao orçamento da operação
+// para todos os tipos
+func (l *latencyBudgets) lookup(hookType, operation string) (LatencyBudget, bool) {
+	if l == nil {
+		return LatencyBudget{}, false
+	}
+	if budget, ok := l.budgets[latencyBudgetKey(hookType, operation)]; ok {
+		return budget, true
+	}
+	budget, ok := l.budgets[latencyBudgetKey("", operation)]
+	return budget, ok
+}
+
+// latencyBudgetMetricDefinitions retorna as métricas dos orçamentos de latência com o namespace indicado
+func latencyBudgetMetricDefinitions(namespace string) []MetricDefinition {
+	return []MetricDefinition{
+		hookLatencyBudgetExceededMetric.WithNamespace(namespace),
+	}
+}
+
+// setupLatencyBudgets associa o contador de orçamentos excedidos às métricas registadas
+func (h *HookObservability) setupLatencyBudgets(instruments map[string]*metricInstrument) {
+	if h.config.LatencyBudgets == nil {
+		return
+	}
+	defs := latencyBudgetMetricDefinitions(h.metricNamespace())
+	h.hookLatencyBudgetExceeded = instruments[defs[0].Name]
+}
+
+// recordLatencyBudgetExceeded regista uma operação que excedeu o orçamento: incrementa a métrica
+// dedicada com o trace como exemplar, marca o span da operação e emite o evento de segurança
+func (h *HookObservability) recordLatencyBudgetExceeded(
+	ctx context.Context,
+	span trace.Span,
+	exemplar prometheus.Labels,
+	marketCtx MarketContext,
+	operation string,
+	userId string,
+	budget LatencyBudget,
+	elapsed time.Duration,
+) {
+	h.hookLatencyBudgetExceeded.recordWithExemplar(1, exemplar,
+		marketCtx.Market,
+		marketCtx.TenantType,
+		marketCtx.HookType,
+		operation,
+	)
+
+	span.SetAttributes(attribute.Bool("latency_budget.exceeded", true))
+	span.AddEvent(LatencyBudgetExceededEvent, trace.WithAttributes(
+		attribute.Int64("latency_budget.elapsed_ms", elapsed.Milliseconds()),
+		attribute.Bool("latency_budget.cancelled", budget.Cancel),
+	))
+
+	details := fmt.Sprintf("Operação %s excedeu o orçamento de latência: %s de %s", budget, elapsed.Round(time.Microsecond), budget.Budget)
+	if budget.Cancel {
+		details += " (contexto cancelado)"
+	}
+	h.TraceSecurity(ctx, marketCtx, userId, h.latencyBudgets.severity, details, LatencyBudgetExceededEvent)
+}
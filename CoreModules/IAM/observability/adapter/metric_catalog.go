@@ -0,0 +1,366 @@
+// Package adapter - catálogo das métricas Prometheus registadas pelo adaptador
+//
+// As definições deste arquivo são a fonte única dos nomes, rótulos e buckets das métricas:
+// setupMetrics cria os coletores a partir delas e o gerador de dashboards e regras de alerta
+// deriva os painéis e as expressões PromQL do mesmo catálogo, mantendo-os sincronizados com o código.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricType identifica o tipo de coletor Prometheus de uma métrica
+type MetricType string
+
+// Tipos de métrica suportados pelo catálogo
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// Grupos de métricas, usados como secções dos dashboards
+const (
+	MetricGroupHooks      = "hooks"
+	MetricGroupElevations = "elevations"
+	MetricGroupValidation = "validation"
+	MetricGroupCompliance = "compliance"
+	MetricGroupPayments   = "payments"
+	MetricGroupBatch      = "batch"
+	MetricGroupSLO        = "slo"
+)
+
+// Nomes das métricas registadas pelo adaptador
+const (
+	MetricHookCallsTotal         = "innovabiz_iam_hook_calls_total"
+	MetricHookErrorsTotal        = "innovabiz_iam_hook_errors_total"
+	MetricHookDurationSeconds    = "innovabiz_iam_hook_duration_seconds"
+	MetricActiveElevations       = "innovabiz_iam_active_elevations"
+	MetricMFAValidationsTotal    = "innovabiz_iam_mfa_validations_total"
+	MetricScopeValidationsTotal  = "innovabiz_iam_scope_validations_total"
+	MetricTestCoveragePercent    = "innovabiz_iam_test_coverage_percent"
+	MetricComplianceEventsTotal  = "innovabiz_iam_compliance_events_total"
+	MetricSecurityEventsTotal    = "innovabiz_iam_security_events_total"
+	MetricPaymentTransactions    = "innovabiz_iam_payment_gateway_transaction_count"
+	MetricPaymentAmount          = "innovabiz_iam_payment_gateway_transaction_amount"
+	MetricPaymentRiskScore       = "innovabiz_iam_payment_gateway_risk_score"
+	MetricPaymentProcessingTime  = "innovabiz_iam_payment_gateway_processing_time"
+	MetricPaymentRuleEvaluations = "innovabiz_iam_payment_gateway_compliance_rule_evaluations"
+	MetricPaymentRuleDurationMs  = "innovabiz_iam_payment_gateway_compliance_rule_duration_ms"
+	MetricPaymentGatewayStatus   = "innovabiz_iam_payment_gateway_status"
+	MetricBatchJobDuration       = "innovabiz_iam_batch_job_duration_seconds"
+	MetricBatchJobSuccess        = "innovabiz_iam_batch_job_success"
+	MetricBatchJobLastSuccess    = "innovabiz_iam_batch_job_last_success_timestamp_seconds"
+	MetricSLOBurnRate            = "innovabiz_iam_slo_burn_rate"
+	MetricSLOErrorBudgetLeft     = "innovabiz_iam_slo_error_budget_remaining_ratio"
+	MetricSLOAlertFiring         = "innovabiz_iam_slo_alert_firing"
+	MetricHookLatencyBudgetTotal = "innovabiz_iam_hook_latency_budget_exceeded_total"
+)
+
+// Rótulos partilhados pelas métricas
+const (
+	labelMarket     = "market"
+	labelTenantType = "tenant_type"
+	labelDimension  = "dimension"
+	labelSLO        = "slo"
+)
+
+// MetricDefinition descreve uma métrica registada pelo adaptador
+type MetricDefinition struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Labels  []string
+	Buckets []float64 // Apenas para histogramas
+
+	// Apresentação nos dashboards
+	Group string
+	Title string
+	Unit  string // Unidade Grafana (s, ms, percent, short)
+}
+
+// HasLabel indica se a métrica tem o rótulo indicado
+func (d MetricDefinition) HasLabel(label string) bool {
+	for _, l := range d.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// collector cria o coletor Prometheus correspondente à definição
+func (d MetricDefinition) collector() prometheus.Collector {
+	switch d.Type {
+	case MetricTypeCounter:
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: d.Name, Help: d.Help}, d.Labels)
+	case MetricTypeGauge:
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: d.Name, Help: d.Help}, d.Labels)
+	default:
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: d.Name, Help: d.Help, Buckets: d.Buckets}, d.Labels)
+	}
+}
+
+// Definições das métricas dos hooks MCP-IAM
+var (
+	hookCallsMetric = MetricDefinition{
+		Name:   MetricHookCallsTotal,
+		Help:   "Total de chamadas de hook MCP-IAM",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, "hook_type", "operation"},
+		Group:  MetricGroupHooks,
+		Title:  "Chamadas de hook por operação",
+		Unit:   "reqps",
+	}
+	hookErrorsMetric = MetricDefinition{
+		Name:   MetricHookErrorsTotal,
+		Help:   "Total de erros em chamadas de hook MCP-IAM",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, "hook_type", "operation"},
+		Group:  MetricGroupHooks,
+		Title:  "Erros de hook por operação",
+		Unit:   "reqps",
+	}
+	hookDurationMetric = MetricDefinition{
+		Name:    MetricHookDurationSeconds,
+		Help:    "Tempo de execução de hooks MCP-IAM em segundos",
+		Type:    MetricTypeHistogram,
+		Labels:  []string{labelMarket, labelTenantType, "hook_type", "operation"},
+		Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2, 5},
+		Group:   MetricGroupHooks,
+		Title:   "Duração dos hooks",
+		Unit:    "s",
+	}
+	activeElevationsMetric = MetricDefinition{
+		Name:   MetricActiveElevations,
+		Help:   "Número de elevações de privilégio ativas por mercado e tipo de tenant",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType},
+		Group:  MetricGroupElevations,
+		Title:  "Elevações de privilégio ativas",
+		Unit:   "short",
+	}
+	mfaValidationsMetric = MetricDefinition{
+		Name:   MetricMFAValidationsTotal,
+		Help:   "Total de validações MFA por mercado, tenant e resultado",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, "level", "result"},
+		Group:  MetricGroupValidation,
+		Title:  "Validações MFA por resultado",
+		Unit:   "reqps",
+	}
+	scopeValidationsMetric = MetricDefinition{
+		Name:   MetricScopeValidationsTotal,
+		Help:   "Total de validações de escopo por mercado, tenant e resultado",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, "scope", "result"},
+		Group:  MetricGroupValidation,
+		Title:  "Validações de escopo por resultado",
+		Unit:   "reqps",
+	}
+	testCoverageMetric = MetricDefinition{
+		Name:   MetricTestCoveragePercent,
+		Help:   "Percentual de cobertura de testes por tipo de hook",
+		Type:   MetricTypeGauge,
+		Labels: []string{"hook_type"},
+		Group:  MetricGroupCompliance,
+		Title:  "Cobertura de testes por tipo de hook",
+		Unit:   "percent",
+	}
+	complianceEventsMetric = MetricDefinition{
+		Name:   MetricComplianceEventsTotal,
+		Help:   "Total de eventos de compliance por mercado e framework",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, "framework"},
+		Group:  MetricGroupCompliance,
+		Title:  "Eventos de compliance por framework",
+		Unit:   "reqps",
+	}
+	securityEventsMetric = MetricDefinition{
+		Name:   MetricSecurityEventsTotal,
+		Help:   "Total de eventos de segurança por mercado e severidade",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, "severity", "event_type"},
+		Group:  MetricGroupCompliance,
+		Title:  "Eventos de segurança por severidade",
+		Unit:   "reqps",
+	}
+)
+
+// paymentMetrics são as métricas do gateway de pagamentos, alimentadas por RecordMetric
+// e RecordHistogram; o rótulo dimension recebe o detalhe informado pelo gateway
+// (tipo de pagamento, moeda, regra de compliance, estado)
+var paymentMetrics = []MetricDefinition{
+	{
+		Name:   MetricPaymentTransactions,
+		Help:   "Total de transações processadas pelo gateway de pagamentos por tipo de pagamento",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, labelDimension},
+		Group:  MetricGroupPayments,
+		Title:  "Transações por tipo de pagamento",
+		Unit:   "reqps",
+	},
+	{
+		Name:    MetricPaymentAmount,
+		Help:    "Montante das transações do gateway de pagamentos por moeda",
+		Type:    MetricTypeHistogram,
+		Labels:  []string{labelMarket, labelTenantType, labelDimension},
+		Buckets: []float64{10, 100, 1000, 10000, 100000, 1000000},
+		Group:   MetricGroupPayments,
+		Title:   "Montante das transações",
+		Unit:    "short",
+	},
+	{
+		Name:    MetricPaymentRiskScore,
+		Help:    "Pontuação de risco das transações do gateway de pagamentos",
+		Type:    MetricTypeHistogram,
+		Labels:  []string{labelMarket, labelTenantType, labelDimension},
+		Buckets: []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100},
+		Group:   MetricGroupPayments,
+		Title:   "Pontuação de risco",
+		Unit:    "short",
+	},
+	{
+		Name:   MetricPaymentProcessingTime,
+		Help:   "Último tempo de processamento de transações em milissegundos por tipo de pagamento",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType, labelDimension},
+		Group:  MetricGroupPayments,
+		Title:  "Tempo de processamento",
+		Unit:   "ms",
+	},
+	{
+		Name:   MetricPaymentRuleEvaluations,
+		Help:   "Total de avaliações de regras de compliance do gateway de pagamentos",
+		Type:   MetricTypeCounter,
+		Labels: []string{labelMarket, labelTenantType, labelDimension},
+		Group:  MetricGroupPayments,
+		Title:  "Avaliações de regras de compliance",
+		Unit:   "reqps",
+	},
+	{
+		Name:    MetricPaymentRuleDurationMs,
+		Help:    "Duração das avaliações de regras de compliance em milissegundos",
+		Type:    MetricTypeHistogram,
+		Labels:  []string{labelMarket, labelTenantType, labelDimension},
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500},
+		Group:   MetricGroupPayments,
+		Title:   "Duração das regras de compliance",
+		Unit:    "ms",
+	},
+	{
+		Name:   MetricPaymentGatewayStatus,
+		Help:   "Estado do gateway de pagamentos (1 no estado indicado)",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType, labelDimension},
+		Group:  MetricGroupPayments,
+		Title:  "Estado do gateway",
+		Unit:   "short",
+	},
+}
+
+// Definições do resumo dos jobs em lote, enviado ao Pushgateway por BatchJob.Finish
+var (
+	batchJobDurationMetric = MetricDefinition{
+		Name:   MetricBatchJobDuration,
+		Help:   "Duração da última execução do job em lote em segundos",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType, labelBatchJob},
+		Group:  MetricGroupBatch,
+		Title:  "Duração da última execução",
+		Unit:   "s",
+	}
+	batchJobSuccessMetric = MetricDefinition{
+		Name:   MetricBatchJobSuccess,
+		Help:   "Resultado da última execução do job em lote (1 sucesso, 0 falha)",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType, labelBatchJob},
+		Group:  MetricGroupBatch,
+		Title:  "Resultado da última execução",
+		Unit:   "short",
+	}
+	batchJobLastSuccessMetric = MetricDefinition{
+		Name:   MetricBatchJobLastSuccess,
+		Help:   "Instante Unix da última execução do job em lote concluída com sucesso",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelTenantType, labelBatchJob},
+		Group:  MetricGroupBatch,
+		Title:  "Último sucesso",
+		Unit:   "dateTimeAsIso",
+	}
+)
+
+// Definições das métricas dos SLOs, calculadas pelo adaptador a partir dos seus histogramas
+// e registadas apenas quando há SLOs configurados
+var (
+	sloBurnRateMetric = MetricDefinition{
+		Name:   MetricSLOBurnRate,
+		Help:   "Taxa de consumo do orçamento de erro do SLO por janela (1 consome o orçamento no período)",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelSLO, "window"},
+		Group:  MetricGroupSLO,
+		Title:  "Burn rate por janela",
+		Unit:   "short",
+	}
+	sloErrorBudgetMetric = MetricDefinition{
+		Name:   MetricSLOErrorBudgetLeft,
+		Help:   "Proporção do orçamento de erro do SLO ainda disponível no período",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelSLO},
+		Group:  MetricGroupSLO,
+		Title:  "Orçamento de erro restante",
+		Unit:   "percentunit",
+	}
+	sloAlertFiringMetric = MetricDefinition{
+		Name:   MetricSLOAlertFiring,
+		Help:   "Alerta de burn rate do SLO ativo por severidade (1 ativo, 0 inativo)",
+		Type:   MetricTypeGauge,
+		Labels: []string{labelMarket, labelSLO, "severity"},
+		Group:  MetricGroupSLO,
+		Title:  "Alertas de burn rate ativos",
+		Unit:   "short",
+	}
+)
+
+// Definição da métrica dos orçamentos de latência, registada apenas quando há orçamentos configurados
+var hookLatencyBudgetExceededMetric = MetricDefinition{
+	Name:   MetricHookLatencyBudgetTotal,
+	Help:   "Total de operações de hook que excederam o orçamento de latência configurado",
+	Type:   MetricTypeCounter,
+	Labels: []string{labelMarket, labelTenantType, "hook_type", "operation"},
+	Group:  MetricGroupHooks,
+	Title:  "Orçamentos de latência excedidos",
+	Unit:   "reqps",
+}
+
+// MetricCatalog retorna as definições de todas as métricas registadas pelo adaptador,
+// pela ordem de apresentação nos dashboards
+func MetricCatalog() []MetricDefinition {
+	catalog := []MetricDefinition{
+		hookCallsMetric,
+		hookErrorsMetric,
+		hookDurationMetric,
+		hookLatencyBudgetExceededMetric,
+		activeElevationsMetric,
+		mfaValidationsMetric,
+		scopeValidationsMetric,
+		complianceEventsMetric,
+		securityEventsMetric,
+		testCoverageMetric,
+	}
+	catalog = append(catalog, paymentMetrics...)
+	catalog = append(catalog, batchJobDurationMetric, batchJobSuccessMetric, batchJobLastSuccessMetric)
+	return append(catalog, sloBurnRateMetric, sloErrorBudgetMetric, sloAlertFiringMetric)
+}
+
+// LookupMetric procura uma métrica do catálogo pelo nome
+func LookupMetric(name string) (MetricDefinition, bool) {
+	for _, def := range MetricCatalog() {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return MetricDefinition{}, false
+}
@@ -0,0 +1,345 @@
+// Package adapter - namespaces das métricas e registo em registos Prometheus ou meters OTel partilhados
+//
+// Vários módulos no mesmo processo (ex.: gateway de pagamentos com o cliente do bureau embebido)
+// criam cada um o seu adaptador. Cada instância prefixa os nomes do catálogo com o seu namespace
+// e regista as métricas no registo Prometheus e no meter OpenTelemetry indicados na configuração.
+// O detetor de conflitos recusa o registo de um nome já registado no mesmo destino por outra
+// instância, com um erro que identifica as duas, em vez do pânico de MustRegister.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultMetricNamespace é o namespace dos nomes do catálogo
+const DefaultMetricNamespace = "innovabiz_iam"
+
+// metricsInstrumentationScope identifica o adaptador nos meters OpenTelemetry
+const metricsInstrumentationScope = "github.com/innovabiz/iam/observability/adapter"
+
+// ErrMetricConflict indica que uma métrica já está registada no destino por outra instância
+var ErrMetricConflict = errors.New("métrica já registada no destino")
+
+// metricNamespacePattern restringe os namespaces aos caracteres válidos em nomes Prometheus
+var metricNamespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateMetricNamespace valida um namespace de métricas
+func ValidateMetricNamespace(namespace string) error {
+	if !metricNamespacePattern.MatchString(namespace) {
+		return fmt.Errorf("namespace de métricas inválido: %q", namespace)
+	}
+	return nil
+}
+
+// WithNamespace retorna a definição com o namespace indicado no lugar de DefaultMetricNamespace
+func (d MetricDefinition) WithNamespace(namespace string) MetricDefinition {
+	if namespace == "" || namespace == DefaultMetricNamespace {
+		return d
+	}
+	d.Name = namespace + strings.TrimPrefix(d.Name, DefaultMetricNamespace)
+	return d
+}
+
+// MetricCatalogWithNamespace retorna o catálogo com os nomes prefixados pelo namespace indicado
+func MetricCatalogWithNamespace(namespace string) []MetricDefinition {
+	catalog := MetricCatalog()
+	for i := range catalog {
+		catalog[i] = catalog[i].WithNamespace(namespace)
+	}
+	return catalog
+}
+
+// metricClaim identifica a instância que registou um nome num destino
+type metricClaim struct {
+	owner *HookObservability
+	label string
+}
+
+// metricClaims guarda, por destino (registo Prometheus ou MeterProvider), os nomes registados
+// pelas instâncias do adaptador no processo
+var metricClaims = struct {
+	sync.Mutex
+	targets map[interface{}]map[string]metricClaim
+}{targets: make(map[interface{}]map[string]metricClaim)}
+
+// claimMetrics reserva os nomes no destino para a instância, sem reservar nenhum quando algum
+// já pertence a outra instância
+func claimMetrics(target interface{}, targetKind string, owner *HookObservability, names []string) error {
+	metricClaims.Lock()
+	defer metricClaims.Unlock()
+
+	claims := metricClaims.targets[target]
+	for _, name := range names {
+		if claim, exists := claims[name]; exists && claim.owner != owner {
+			return fmt.Errorf("%w: %s no %s já foi registada por %s; configure um MetricNamespace distinto para %s",
+				ErrMetricConflict, name, targetKind, claim.label, owner.metricsOwnerLabel())
+		}
+	}
+	if claims == nil {
+		claims = make(map[string]metricClaim, len(names))
+		metricClaims.targets[target] = claims
+	}
+	for _, name := range names {
+		claims[name] = metricClaim{owner: owner, label: owner.metricsOwnerLabel()}
+	}
+	return nil
+}
+
+// releaseMetrics liberta os nomes reservados pela instância no destino
+func releaseMetrics(target interface{}, owner *HookObservability) {
+	metricClaims.Lock()
+	defer metricClaims.Unlock()
+
+	claims := metricClaims.targets[target]
+	for name, claim := range claims {
+		if claim.owner == owner {
+			delete(claims, name)
+		}
+	}
+	if len(claims) == 0 {
+		delete(metricClaims.targets, target)
+	}
+}
+
+// metricsOwnerLabel descreve a instância nas mensagens de conflito
+func (h *HookObservability) metricsOwnerLabel() string {
+	return fmt.Sprintf("%s (namespace %s)", h.config.ServiceName, h.metricNamespace())
+}
+
+// metricNamespace retorna o namespace das métricas da instância
+func (h *HookObservability) metricNamespace() string {
+	if h.config.MetricNamespace == "" {
+		return DefaultMetricNamespace
+	}
+	return h.config.MetricNamespace
+}
+
+// metricInstrument regista os valores de uma métrica no coletor Prometheus e, quando há
+// MeterProvider, no instrumento OpenTelemetry equivalente. Contadores são incrementados,
+// gauges recebem o valor e histogramas registam uma observação; uma métrica nil ignora os valores
+type metricInstrument struct {
+	def       MetricDefinition
+	collector prometheus.Collector
+	counter   metric.Float64Counter
+	histogram metric.Float64Histogram
+	gauge     *otelGaugeValues
+
+	// Métrica desativada em tempo de execução, que ignora os valores
+	disabled atomic.Bool
+}
+
+// record aplica o valor com os rótulos pela ordem de def.Labels
+func (m *metricInstrument) record(value float64, labels ...string) {
+	m.recordWithExemplar(value, nil, labels...)
+}
+
+// recordWithExemplar aplica o valor e, em contadores e histogramas Prometheus, associa-lhe o
+// exemplar indicado (ex.: trace_id do span), exposto no formato OpenMetrics
+func (m *metricInstrument) recordWithExemplar(value float64, exemplar prometheus.Labels, labels ...string) {
+	if m == nil || m.disabled.Load() {
+		return
+	}
+
+	switch c := m.collector.(type) {
+	case *prometheus.CounterVec:
+		counter := c.WithLabelValues(labels...)
+		if adder, ok := counter.(prometheus.ExemplarAdder); ok && len(exemplar) > 0 {
+			adder.AddWithExemplar(value, exemplar)
+		} else {
+			counter.Add(value)
+		}
+	case *prometheus.GaugeVec:
+		c.WithLabelValues(labels...).Set(value)
+	case *prometheus.HistogramVec:
+		observer := c.WithLabelValues(labels...)
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && len(exemplar) > 0 {
+			exemplarObserver.ObserveWithExemplar(value, exemplar)
+		} else {
+			observer.Observe(value)
+		}
+	}
+
+	if m.counter == nil && m.histogram == nil && m.gauge == nil {
+		return
+	}
+	attrs := make([]attribute.KeyValue, 0, len(labels))
+	for i, label := range labels {
+		if i < len(m.def.Labels) {
+			attrs = append(attrs, attribute.String(m.def.Labels[i], label))
+		}
+	}
+	set := attribute.NewSet(attrs...)
+	switch {
+	case m.counter != nil:
+		m.counter.Add(context.Background(), value, metric.WithAttributeSet(set))
+	case m.histogram != nil:
+		m.histogram.Record(context.Background(), value, metric.WithAttributeSet(set))
+	default:
+		m.gauge.set(set, value)
+	}
+}
+
+// otelGaugeValues guarda o último valor de cada combinação de rótulos de um gauge, lido pelo
+// callback do instrumento observável (a API OpenTelemetry não tem gauges síncronos)
+type otelGaugeValues struct {
+	instrument metric.Float64ObservableGauge
+
+	mutex  sync.Mutex
+	values map[attribute.Distinct]otelGaugeValue
+}
+
+// otelGaugeValue é o valor atual de um gauge para um conjunto de atributos
+type otelGaugeValue struct {
+	attrs attribute.Set
+	value float64
+}
+
+// set substitui o valor do gauge para o conjunto de atributos
+func (g *otelGaugeValues) set(attrs attribute.Set, value float64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.values[attrs.Equivalent()] = otelGaugeValue{attrs: attrs, value: value}
+}
+
+// observe reporta os valores atuais ao meter
+func (g *otelGaugeValues) observe(_ context.Context, observer metric.Observer) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, current := range g.values {
+		observer.ObserveFloat64(g.instrument, current.value, metric.WithAttributeSet(current.attrs))
+	}
+	return nil
+}
+
+// registerMetrics cria e regista as métricas do catálogo com o namespace da instância no registo
+// Prometheus e, quando configurado, no MeterProvider. Em caso de conflito nada fica registado
+func (h *HookObservability) registerMetrics(registry *prometheus.Registry, defs []MetricDefinition) (map[string]*metricInstrument, error) {
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+
+	if err := claimMetrics(registry, "registo Prometheus", h, names); err != nil {
+		return nil, err
+	}
+	if provider := h.config.MeterProvider; provider != nil {
+		if err := claimMetrics(provider, "MeterProvider", h, names); err != nil {
+			releaseMetrics(registry, h)
+			return nil, err
+		}
+	}
+
+	instruments := make(map[string]*metricInstrument, len(defs))
+	registered := make([]prometheus.Collector, 0, len(defs))
+	callbacks := make([]metric.Registration, 0)
+	fail := func(err error) (map[string]*metricInstrument, error) {
+		for _, collector := range registered {
+			registry.Unregister(collector)
+		}
+		for _, callback := range callbacks {
+			callback.Unregister()
+		}
+		h.releaseMetricClaims(registry)
+		return nil, err
+	}
+
+	var meter metric.Meter
+	if h.config.MeterProvider != nil {
+		meter = h.config.MeterProvider.Meter(metricsInstrumentationScope)
+	}
+	for _, def := range defs {
+		instrument := &metricInstrument{def: def, collector: def.collector()}
+		if err := registry.Register(instrument.collector); err != nil {
+			var already prometheus.AlreadyRegisteredError
+			if errors.As(err, &already) {
+				err = fmt.Errorf("%w: %s já existe no registo Prometheus, registada fora do adaptador", ErrMetricConflict, def.Name)
+			}
+			return fail(fmt.Errorf("falha ao registar métrica %s: %w", def.Name, err))
+		}
+		registered = append(registered, instrument.collector)
+
+		if meter != nil {
+			callback, err := instrument.createOTelInstrument(meter)
+			if err != nil {
+				return fail(fmt.Errorf("falha ao criar instrumento OpenTelemetry %s: %w", def.Name, err))
+			}
+			if callback != nil {
+				callbacks = append(callbacks, callback)
+			}
+		}
+		instruments[def.Name] = instrument
+	}
+
+	h.registeredCollectors = registered
+	h.metricCallbacks = callbacks
+	return instruments, nil
+}
+
+// createOTelInstrument cria no meter o instrumento equivalente à definição. Para gauges retorna
+// o registo do callback, removido quando a instância é fechada
+func (m *metricInstrument) createOTelInstrument(meter metric.Meter) (metric.Registration, error) {
+	var err error
+	switch m.def.Type {
+	case MetricTypeCounter:
+		m.counter, err = meter.Float64Counter(m.def.Name, metric.WithDescription(m.def.Help))
+		return nil, err
+	case MetricTypeGauge:
+		gauge := &otelGaugeValues{values: make(map[attribute.Distinct]otelGaugeValue)}
+		gauge.instrument, err = meter.Float64ObservableGauge(m.def.Name, metric.WithDescription(m.def.Help))
+		if err != nil {
+			return nil, err
+		}
+		callback, err := meter.RegisterCallback(gauge.observe, gauge.instrument)
+		if err != nil {
+			return nil, err
+		}
+		m.gauge = gauge
+		return callback, nil
+	default:
+		m.histogram, err = meter.Float64Histogram(m.def.Name,
+			metric.WithDescription(m.def.Help),
+			metric.WithExplicitBucketBoundaries(m.def.Buckets...))
+		return nil, err
+	}
+}
+
+// unregisterMetrics retira as métricas da instância do registo Prometheus e os callbacks dos
+// gauges do meter e liberta os nomes, permitindo que outra instância os registe no mesmo destino
+func (h *HookObservability) unregisterMetrics() error {
+	if h.metricsRegistry == nil {
+		return nil
+	}
+	for _, collector := range h.registeredCollectors {
+		h.metricsRegistry.Unregister(collector)
+	}
+	var errs []error
+	for _, callback := range h.metricCallbacks {
+		if err := callback.Unregister(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	h.registeredCollectors = nil
+	h.metricCallbacks = nil
+	h.releaseMetricClaims(h.metricsRegistry)
+	return errors.Join(errs...)
+}
+
+// releaseMetricClaims liberta os nomes da instância no registo e no MeterProvider
+func (h *HookObservability) releaseMetricClaims(registry *prometheus.Registry) {
+	releaseMetrics(registry, h)
+	if h.config.MeterProvider != nil {
+		releaseMetrics(h.config.MeterProvider, h)
+	}
+}
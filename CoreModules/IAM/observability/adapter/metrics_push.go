@@ -0,0 +1,287 @@
+// Package adapter - envio das métricas dos jobs em lote para o Pushgateway
+//
+// Os jobs em lote (reconciliação, geração de relatórios) terminam antes de o Prometheus os
+// recolher. Com o envio ativo, cada execução é delimitada por StartBatchJob e BatchJob.Finish:
+// ao terminar, o resumo do job (duração, resultado e instante do último sucesso) é acrescentado
+// ao grupo do job, que se mantém entre execuções, e as métricas do adaptador são enviadas para
+// o grupo da execução, identificado também pela instância.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// Valores padrão do envio de métricas
+const (
+	DefaultMetricsPushTimeout = 10 * time.Second
+
+	groupingJob      = "job"
+	groupingInstance = "instance"
+	labelBatchJob    = "batch_job"
+)
+
+// PushConfig define o envio das métricas dos jobs em lote para um Pushgateway
+type PushConfig struct {
+	// URL base do Pushgateway, sem o caminho /metrics/job (ex: http://pushgateway:9091)
+	URL string
+
+	// Instância do grupo de cada execução (padrão: nome do host)
+	Instance string
+
+	// Rótulos adicionais dos grupos (ex: region); não podem repetir job nem instance
+	Grouping map[string]string
+
+	// Remover o grupo da execução quando o job termina com sucesso, em vez de o enviar.
+	// Evita a acumulação de grupos quando cada execução tem instância própria (ex: pods de
+	// CronJob); os grupos das execuções falhadas ficam para investigação e o resumo do job
+	// é sempre mantido
+	DeleteOnSuccess bool
+
+	// Tempo máximo de cada pedido ao Pushgateway (padrão: 10s)
+	Timeout time.Duration
+
+	// Cliente HTTP dos pedidos (substitui o cliente padrão com Timeout quando definido)
+	Client *http.Client `json:"-"`
+}
+
+// Validate valida a configuração do envio de métricas
+func (c PushConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("URL do Pushgateway não configurada")
+	}
+	parsed, err := url.Parse(c.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("URL do Pushgateway inválida: %s", c.URL)
+	}
+	for key, value := range c.Grouping {
+		if key == "" || value == "" {
+			return fmt.Errorf("rótulo de agrupamento vazio: %q=%q", key, value)
+		}
+		if key == groupingJob || key == groupingInstance {
+			return fmt.Errorf("rótulo de agrupamento reservado: %s", key)
+		}
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout do Pushgateway inválido: %s", c.Timeout)
+	}
+	return nil
+}
+
+// MetricsPusher envia métricas para um grupo identificado pelos rótulos de agrupamento,
+// que incluem sempre job
+type MetricsPusher interface {
+	// Push substitui todas as métricas do grupo pelas do gatherer
+	Push(ctx context.Context, gatherer prometheus.Gatherer, grouping map[string]string) error
+
+	// Add substitui no grupo apenas as métricas com o mesmo nome das do gatherer
+	Add(ctx context.Context, gatherer prometheus.Gatherer, grouping map[string]string) error
+
+	// Delete remove o grupo e todas as suas métricas
+	Delete(ctx context.Context, grouping map[string]string) error
+}
+
+// PushgatewayPusher envia métricas para um Prometheus Pushgateway
+type PushgatewayPusher struct {
+	url    string
+	client *http.Client
+}
+
+// NewPushgatewayPusher cria o cliente do Pushgateway indicado na configuração
+func NewPushgatewayPusher(config PushConfig) *PushgatewayPusher {
+	client := config.Client
+	if client == nil {
+		timeout := config.Timeout
+		if timeout == 0 {
+			timeout = DefaultMetricsPushTimeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	return &PushgatewayPusher{url: config.URL, client: client}
+}
+
+// Push substitui as métricas do grupo (PUT)
+func (p *PushgatewayPusher) Push(ctx context.Context, gatherer prometheus.Gatherer, grouping map[string]string) error {
+	return p.pusher(grouping).Gatherer(gatherer).PushContext(ctx)
+}
+
+// Add acrescenta ou substitui métricas no grupo (POST)
+func (p *PushgatewayPusher) Add(ctx context.Context, gatherer prometheus.Gatherer, grouping map[string]string) error {
+	return p.pusher(grouping).Gatherer(gatherer).AddContext(ctx)
+}
+
+// Delete remove o grupo (DELETE); o tempo máximo é o do cliente HTTP
+func (p *PushgatewayPusher) Delete(ctx context.Context, grouping map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.pusher(grouping).Delete()
+}
+
+// pusher cria o pedido para o grupo, com os rótulos por ordem para um URL estável
+func (p *PushgatewayPusher) pusher(grouping map[string]string) *push.Pusher {
+	pusher := push.New(p.url, grouping[groupingJob]).Client(p.client)
+
+	keys := make([]string, 0, len(grouping))
+	for key := range grouping {
+		if key != groupingJob {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pusher = pusher.Grouping(key, grouping[key])
+	}
+	return pusher
+}
+
+// BatchJob delimita uma execução de um job em lote cujas métricas são enviadas ao terminar
+type BatchJob struct {
+	obs       *HookObservability
+	marketCtx MarketContext
+	name      string
+	startedAt time.Time
+
+	mutex    sync.Mutex
+	finished bool
+}
+
+// StartBatchJob marca o início de uma execução do job em lote indicado
+func (h *HookObservability) StartBatchJob(marketCtx MarketContext, name string) *BatchJob {
+	h.logger.Info("Job em lote iniciado",
+		zap.String("batch_job", name),
+		zap.String("market", marketCtx.Market),
+		zap.String("tenant_type", marketCtx.TenantType),
+	)
+	return &BatchJob{
+		obs:       h,
+		marketCtx: marketCtx,
+		name:      name,
+		startedAt: time.Now(),
+	}
+}
+
+// Finish termina a execução com o resultado do job (nil em caso de sucesso) e envia as
+// métricas quando o envio está configurado. Retorna o erro do envio; uma execução só
+// pode ser terminada uma vez
+func (j *BatchJob) Finish(ctx context.Context, jobErr error) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.finished {
+		return fmt.Errorf("job em lote %s já terminado", j.name)
+	}
+	j.finished = true
+
+	h := j.obs
+	duration := time.Since(j.startedAt)
+	logger := h.logger.With(
+		zap.String("batch_job", j.name),
+		zap.String("market", j.marketCtx.Market),
+		zap.String("tenant_type", j.marketCtx.TenantType),
+		zap.Float64("duration_seconds", duration.Seconds()),
+	)
+	if jobErr != nil {
+		logger.Error("Job em lote terminado com falha", zap.Error(jobErr))
+	} else {
+		logger.Info("Job em lote concluído com sucesso")
+	}
+
+	if h.pusher == nil {
+		return nil
+	}
+
+	jobGrouping := map[string]string{groupingJob: j.name}
+	for key, value := range h.push.Grouping {
+		jobGrouping[key] = value
+	}
+	runGrouping := map[string]string{groupingInstance: h.push.Instance}
+	for key, value := range jobGrouping {
+		runGrouping[key] = value
+	}
+
+	var errs []error
+
+	// Resumo do job: Add preserva o instante do último sucesso quando a execução falha
+	if err := h.pusher.Add(ctx, j.summary(duration, jobErr == nil), jobGrouping); err != nil {
+		errs = append(errs, fmt.Errorf("erro ao enviar resumo do job: %w", err))
+	}
+
+	// Métricas da execução
+	if jobErr == nil && h.push.DeleteOnSuccess {
+		if err := h.pusher.Delete(ctx, runGrouping); err != nil {
+			errs = append(errs, fmt.Errorf("erro ao remover grupo da execução: %w", err))
+		}
+	} else if h.metricsRegistry != nil {
+		if err := h.pusher.Push(ctx, h.metricsRegistry, runGrouping); err != nil {
+			errs = append(errs, fmt.Errorf("erro ao enviar métricas da execução: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		logger.Error("Falha ao enviar métricas do job em lote", zap.Errors("errors", errs))
+		return fmt.Errorf("erros ao enviar métricas do job em lote %s: %v", j.name, errs)
+	}
+
+	logger.Debug("Métricas do job em lote enviadas")
+	return nil
+}
+
+// summary cria o registo com as métricas de resumo da execução, apenas para este job
+func (j *BatchJob) summary(duration time.Duration, success bool) *prometheus.Registry {
+	labels := []string{j.marketCtx.Market, j.marketCtx.TenantType, j.name}
+	namespace := j.obs.metricNamespace()
+
+	durationGauge := batchJobDurationMetric.WithNamespace(namespace).collector().(*prometheus.GaugeVec)
+	durationGauge.WithLabelValues(labels...).Set(duration.Seconds())
+
+	successGauge := batchJobSuccessMetric.WithNamespace(namespace).collector().(*prometheus.GaugeVec)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(durationGauge, successGauge)
+
+	if success {
+		successGauge.WithLabelValues(labels...).Set(1)
+
+		lastSuccess := batchJobLastSuccessMetric.WithNamespace(namespace).collector().(*prometheus.GaugeVec)
+		lastSuccess.WithLabelValues(labels...).Set(float64(time.Now().Unix()))
+		registry.MustRegister(lastSuccess)
+	} else {
+		successGauge.WithLabelValues(labels...).Set(0)
+	}
+	return registry
+}
+
+// setupMetricsPush configura o envio das métricas dos jobs em lote
+func (h *HookObservability) setupMetricsPush() {
+	if h.config.MetricsPush != nil {
+		h.push = *h.config.MetricsPush
+	}
+	if h.config.MetricsPusher == nil && h.config.MetricsPush == nil {
+		return
+	}
+
+	if h.push.Instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = h.config.ServiceName
+		}
+		h.push.Instance = hostname
+	}
+
+	h.pusher = h.config.MetricsPusher
+	if h.pusher == nil {
+		h.pusher = NewPushgatewayPusher(h.push)
+	}
+}
@@ -0,0 +1,166 @@
+// Package adapter fornece redação de dados pessoais (PII) para o adaptador de observabilidade MCP-IAM
+//
+// Este arquivo define o redator de PII aplicado a logs, atributos de spans e logs de compliance,
+// evitando que documentos e nomes de clientes sejam emitidos em texto claro.
+//
+// Conformidades: LGPD, GDPR, Lei 22/11 (Angola), ISO/IEC 27701
+package adapter
+
+import (
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Valor usado para substituir campos sensíveis sem representação textual
+const redactedValue = "[REDACTED]"
+
+// defaultSensitiveKeys lista as chaves de campos tratadas como PII por padrão
+var defaultSensitiveKeys = []string{
+	"documento_cliente",
+	"documentocliente",
+	"nome_cliente",
+	"nomecliente",
+	"documento",
+	"cpf",
+	"cnpj",
+	"nif",
+	"bi",
+	"nuit",
+	"ssn",
+	"email",
+	"telefone",
+	"phone",
+}
+
+// defaultPIIPatterns reconhece documentos e contactos embutidos em texto livre
+var defaultPIIPatterns = []*regexp.Regexp{
+	// CPF (Brasil)
+	regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`),
+	// CNPJ (Brasil)
+	regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}\b`),
+	// Bilhete de Identidade (Angola)
+	regexp.MustCompile(`\b\d{9}[A-Z]{2}\d{3}\b`),
+	// Endereços de email
+	regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+}
+
+// PIIRedactor aplica redação de PII a mensagens, campos de log e atributos de spans
+type PIIRedactor struct {
+	sensitiveKeys map[string]bool
+	patterns      []*regexp.Regexp
+}
+
+// NewPIIRedactor cria um redator com as chaves padrão e chaves adicionais
+func NewPIIRedactor(extraKeys ...string) *PIIRedactor {
+	r := &PIIRedactor{
+		sensitiveKeys: make(map[string]bool),
+		patterns:      defaultPIIPatterns,
+	}
+	for _, key := range append(defaultSensitiveKeys, extraKeys...) {
+		r.sensitiveKeys[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+// IsSensitiveKey verifica se a chave corresponde a um campo de PII
+func (r *PIIRedactor) IsSensitiveKey(key string) bool {
+	return r.sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactString mascara documentos e contactos reconhecidos em texto livre
+func (r *PIIRedactor) RedactString(s string) string {
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllStringFunc(s, MaskPII)
+	}
+	return s
+}
+
+// RedactAttributes retorna uma cópia dos atributos com valores de PII mascarados
+func (r *PIIRedactor) RedactAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		switch {
+		case r.IsSensitiveKey(string(attr.Key)):
+			if attr.Value.Type() == attribute.STRING {
+				redacted[i] = attribute.String(string(attr.Key), MaskPII(attr.Value.AsString()))
+			} else {
+				redacted[i] = attribute.String(string(attr.Key), redactedValue)
+			}
+		case attr.Value.Type() == attribute.STRING:
+			redacted[i] = attribute.String(string(attr.Key), r.RedactString(attr.Value.AsString()))
+		default:
+			redacted[i] = attr
+		}
+	}
+	return redacted
+}
+
+// RedactFields retorna uma cópia dos campos de log com valores de PII mascarados
+func (r *PIIRedactor) RedactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch {
+		case r.IsSensitiveKey(field.Key):
+			if field.Type == zapcore.StringType {
+				redacted[i] = zap.String(field.Key, MaskPII(field.String))
+			} else {
+				redacted[i] = zap.String(field.Key, redactedValue)
+			}
+		case field.Type == zapcore.StringType:
+			redacted[i] = zap.String(field.Key, r.RedactString(field.String))
+		default:
+			redacted[i] = field
+		}
+	}
+	return redacted
+}
+
+// WrapCore envolve um core Zap para redigir PII em todas as entradas de log
+func (r *PIIRedactor) WrapCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core, redactor: r}
+}
+
+// MaskPII mascara um valor mantendo apenas os últimos caracteres para correlação
+// Valores já mascarados são mantidos, para que a redação possa ser aplicada mais de uma vez
+func MaskPII(value string) string {
+	if strings.HasPrefix(value, "***") {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) <= 6 {
+		return "***"
+	}
+	return "***" + string(runes[len(runes)-2:])
+}
+
+// redactingCore é um zapcore.Core que aplica redação de PII antes da escrita
+type redactingCore struct {
+	zapcore.Core
+	redactor *PIIRedactor
+}
+
+// With adiciona campos de contexto já redigidos
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{
+		Core:     c.Core.With(c.redactor.RedactFields(fields)),
+		redactor: c.redactor,
+	}
+}
+
+// Check delega a verificação de nível e regista este core para escrita
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write redige a mensagem e os campos antes de delegar ao core original
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.RedactString(entry.Message)
+	return c.Core.Write(entry, c.redactor.RedactFields(fields))
+}
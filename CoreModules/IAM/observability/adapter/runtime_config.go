@@ -0,0 +1,624 @@
+// Package adapter - configuração de observabilidade alterada em tempo de execução
+//
+// O nível de log, a taxa de amostragem dos traces e a ativação de cada métrica podem ser alterados
+// sem reiniciar o serviço, pelo endpoint administrativo autenticado ou por UpdateRuntimeConfig.
+// Cada alteração fica no histórico da instância e é registada como evento de auditoria com o
+// operador e o motivo. Uma alteração temporária (ex.: nível debug durante um incidente) indica a
+// duração após a qual a configuração anterior é reposta automaticamente.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/innovabiz/iam/constants"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Valores padrão da configuração em tempo de execução
+const (
+	DefaultRuntimeAdminPath    = "/admin/observability"
+	DefaultRuntimeMaxRevert    = 24 * time.Hour
+	DefaultRuntimeHistorySize  = 100
+	runtimeAdminMinTokenLength = 32
+	runtimeAdminMaxBodySize    = 64 << 10
+
+	// Operador registado nas reversões automáticas
+	RuntimeRevertOperator = "system:auto-revert"
+
+	// Tipo do evento de auditoria das alterações
+	runtimeAuditEventType = "observability_runtime_config"
+	runtimeAuditHookType  = "ObservabilityAdmin"
+)
+
+// Erros da configuração em tempo de execução
+var (
+	ErrInvalidRuntimeChange = errors.New("alteração de configuração inválida")
+	ErrNoTemporaryChange    = errors.New("nenhuma alteração temporária ativa")
+)
+
+// RuntimeAdminConfig define o endpoint administrativo de alteração da configuração
+type RuntimeAdminConfig struct {
+	// Tokens aceites no cabeçalho Authorization (Bearer), associados ao operador registado na
+	// auditoria; cada token deve ter pelo menos 32 caracteres
+	Tokens map[string]string `json:"-"`
+
+	// Caminho do endpoint no servidor de métricas (padrão: /admin/observability)
+	Path string
+
+	// Duração máxima de uma alteração temporária (padrão: 24h)
+	MaxRevertAfter time.Duration
+
+	// Número de alterações mantidas no histórico da instância (padrão: 100)
+	HistorySize int
+}
+
+// Validate valida a configuração do endpoint administrativo
+func (c RuntimeAdminConfig) Validate() error {
+	if len(c.Tokens) == 0 {
+		return fmt.Errorf("nenhum token administrativo configurado")
+	}
+	for token, operator := range c.Tokens {
+		if len(token) < runtimeAdminMinTokenLength {
+			return fmt.Errorf("token administrativo do operador %q com menos de %d caracteres", operator, runtimeAdminMinTokenLength)
+		}
+		if strings.TrimSpace(operator) == "" {
+			return fmt.Errorf("token administrativo sem operador associado")
+		}
+	}
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("caminho do endpoint administrativo inválido: %s", c.Path)
+	}
+	if c.MaxRevertAfter < 0 {
+		return fmt.Errorf("duração máxima das alterações temporárias inválida: %s", c.MaxRevertAfter)
+	}
+	if c.HistorySize < 0 {
+		return fmt.Errorf("tamanho do histórico inválido: %d", c.HistorySize)
+	}
+	return nil
+}
+
+// RuntimeConfig é a configuração de observabilidade em vigor na instância
+type RuntimeConfig struct {
+	LogLevel        string  `json:"log_level"`
+	TraceSampleRate float64 `json:"trace_sample_rate"`
+
+	// Métricas desativadas, pelo nome sem o namespace (ex: hook_calls_total)
+	DisabledMetrics []string `json:"disabled_metrics"`
+
+	// Instante da reposição automática da configuração anterior, quando há alteração temporária
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// RuntimeConfigChange descreve uma alteração; os campos nil ou vazios mantêm o valor em vigor
+type RuntimeConfigChange struct {
+	LogLevel        *string  `json:"log_level,omitempty"`
+	TraceSampleRate *float64 `json:"trace_sample_rate,omitempty"`
+
+	// Ativação das métricas pelo nome sem o namespace (true ativa, false desativa)
+	Metrics map[string]bool `json:"metrics,omitempty"`
+
+	// Duração da alteração; quando positiva, a configuração anterior é reposta no fim
+	RevertAfter time.Duration `json:"-"`
+
+	// Motivo registado na auditoria
+	Reason string `json:"reason,omitempty"`
+}
+
+// RuntimeConfigAudit regista uma alteração da configuração em tempo de execução
+type RuntimeConfigAudit struct {
+	Time     time.Time     `json:"time"`
+	Operator string        `json:"operator"`
+	Reason   string        `json:"reason,omitempty"`
+	Previous RuntimeConfig `json:"previous"`
+	Current  RuntimeConfig `json:"current"`
+
+	// Duração da alteração temporária
+	RevertAfter string `json:"revert_after,omitempty"`
+
+	// Reposição da configuração anterior, automática ou pedida por um operador
+	Revert bool `json:"revert,omitempty"`
+}
+
+// runtimeState guarda a alteração temporária ativa e o histórico das alterações
+type runtimeState struct {
+	mutex sync.Mutex
+
+	// Configuração reposta no fim da alteração temporária ativa (nil sem alteração temporária)
+	baseline    *RuntimeConfig
+	revertAt    time.Time
+	revertTimer *time.Timer
+	generation  uint64
+
+	history []RuntimeConfigAudit
+	closed  bool
+}
+
+// runtimeSampler delega a amostragem na taxa em vigor, que pode ser substituída a qualquer momento
+type runtimeSampler struct {
+	current atomic.Pointer[ratioSampler]
+}
+
+// ratioSampler associa a taxa de amostragem ao sampler correspondente
+type ratioSampler struct {
+	rate    float64
+	sampler sdktrace.Sampler
+}
+
+func newRuntimeSampler(rate float64) *runtimeSampler {
+	s := &runtimeSampler{}
+	s.set(rate)
+	return s
+}
+
+func (s *runtimeSampler) set(rate float64) {
+	s.current.Store(&ratioSampler{rate: rate, sampler: sdktrace.TraceIDRatioBased(rate)})
+}
+
+func (s *runtimeSampler) rate() float64 {
+	return s.current.Load().rate
+}
+
+// ShouldSample implementa sdktrace.Sampler com a taxa em vigor
+func (s *runtimeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.current.Load().sampler.ShouldSample(p)
+}
+
+// Description implementa sdktrace.Sampler
+func (s *runtimeSampler) Description() string {
+	return fmt.Sprintf("RuntimeSampler{%s}", s.current.Load().sampler.Description())
+}
+
+// parseLogLevel converte o nível de log da configuração no nível Zap
+func parseLogLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info", "":
+		return zap.InfoLevel, nil
+	case "warn":
+		return zap.WarnLevel, nil
+	case "error":
+		return zap.ErrorLevel, nil
+	default:
+		return zap.InfoLevel, fmt.Errorf("nível de log inválido: %s", level)
+	}
+}
+
+// RuntimeConfig retorna a configuração de observabilidade em vigor
+func (h *HookObservability) RuntimeConfig() RuntimeConfig {
+	h.runtime.mutex.Lock()
+	defer h.runtime.mutex.Unlock()
+	return h.currentRuntimeConfig()
+}
+
+// RuntimeConfigHistory retorna as alterações da configuração, da mais antiga para a mais recente
+func (h *HookObservability) RuntimeConfigHistory() []RuntimeConfigAudit {
+	h.runtime.mutex.Lock()
+	defer h.runtime.mutex.Unlock()
+	return append([]RuntimeConfigAudit(nil), h.runtime.history...)
+}
+
+// UpdateRuntimeConfig aplica uma alteração da configuração em nome do operador
+//
+// Com RevertAfter, a configuração anterior é reposta no fim; uma nova alteração temporária
+// prolonga o prazo sem mudar a configuração a repor, e uma alteração permanente durante uma
+// alteração temporária é aplicada também à configuração a repor. Em produção, o nível debug
+// só pode ser ativado temporariamente.
+func (h *HookObservability) UpdateRuntimeConfig(ctx context.Context, operator string, change RuntimeConfigChange) (RuntimeConfig, error) {
+	if strings.TrimSpace(operator) == "" {
+		return RuntimeConfig{}, fmt.Errorf("%w: operador não identificado", ErrInvalidRuntimeChange)
+	}
+	if err := h.validateRuntimeChange(change); err != nil {
+		return RuntimeConfig{}, err
+	}
+
+	h.runtime.mutex.Lock()
+	defer h.runtime.mutex.Unlock()
+
+	if h.runtime.closed {
+		return RuntimeConfig{}, fmt.Errorf("adaptador de observabilidade fechado")
+	}
+
+	previous := h.currentRuntimeConfig()
+	next := applyRuntimeChange(previous, change)
+
+	if change.RevertAfter > 0 {
+		if h.runtime.baseline == nil {
+			baseline := previous
+			baseline.RevertAt = nil
+			h.runtime.baseline = &baseline
+		}
+		h.scheduleRevert(change.RevertAfter)
+	} else if h.runtime.baseline != nil {
+		baseline := applyRuntimeChange(*h.runtime.baseline, change)
+		h.runtime.baseline = &baseline
+	}
+
+	h.applyRuntimeConfig(next)
+	current := h.currentRuntimeConfig()
+
+	audit := RuntimeConfigAudit{
+		Time:     time.Now().UTC(),
+		Operator: operator,
+		Reason:   change.Reason,
+		Previous: previous,
+		Current:  current,
+	}
+	if change.RevertAfter > 0 {
+		audit.RevertAfter = change.RevertAfter.String()
+	}
+	h.recordRuntimeAudit(ctx, audit)
+
+	return current, nil
+}
+
+// RevertRuntimeConfig repõe de imediato a configuração anterior à alteração temporária ativa
+func (h *HookObservability) RevertRuntimeConfig(ctx context.Context, operator, reason string) (RuntimeConfig, error) {
+	if strings.TrimSpace(operator) == "" {
+		return RuntimeConfig{}, fmt.Errorf("%w: operador não identificado", ErrInvalidRuntimeChange)
+	}
+
+	h.runtime.mutex.Lock()
+	defer h.runtime.mutex.Unlock()
+
+	if h.runtime.baseline == nil {
+		return RuntimeConfig{}, ErrNoTemporaryChange
+	}
+	return h.revertLocked(ctx, operator, reason), nil
+}
+
+// validateRuntimeChange valida os valores da alteração e a política das alterações temporárias
+func (h *HookObservability) validateRuntimeChange(change RuntimeConfigChange) error {
+	if change.LogLevel == nil && change.TraceSampleRate == nil && len(change.Metrics) == 0 {
+		return fmt.Errorf("%w: nenhuma alteração indicada", ErrInvalidRuntimeChange)
+	}
+
+	if change.LogLevel != nil {
+		level, err := parseLogLevel(*change.LogLevel)
+		if err != nil || strings.TrimSpace(*change.LogLevel) == "" {
+			return fmt.Errorf("%w: nível de log inválido: %q", ErrInvalidRuntimeChange, *change.LogLevel)
+		}
+		if level == zap.DebugLevel && change.RevertAfter <= 0 && strings.EqualFold(h.config.Environment, "production") {
+			return fmt.Errorf("%w: em produção o nível debug exige reversão automática", ErrInvalidRuntimeChange)
+		}
+	}
+
+	if change.TraceSampleRate != nil && (*change.TraceSampleRate < 0 || *change.TraceSampleRate > 1) {
+		return fmt.Errorf("%w: taxa de amostragem inválida: %f, deve estar entre 0.0 e 1.0", ErrInvalidRuntimeChange, *change.TraceSampleRate)
+	}
+
+	for name := range change.Metrics {
+		if _, ok := h.metricsByName[name]; !ok {
+			return fmt.Errorf("%w: métrica desconhecida: %s", ErrInvalidRuntimeChange, name)
+		}
+	}
+
+	if change.RevertAfter < 0 {
+		return fmt.Errorf("%w: duração inválida: %s", ErrInvalidRuntimeChange, change.RevertAfter)
+	}
+	if change.RevertAfter > h.maxRevertAfter() {
+		return fmt.Errorf("%w: duração %s acima do máximo de %s", ErrInvalidRuntimeChange, change.RevertAfter, h.maxRevertAfter())
+	}
+	return nil
+}
+
+// applyRuntimeChange retorna a configuração com a alteração aplicada
+func applyRuntimeChange(config RuntimeConfig, change RuntimeConfigChange) RuntimeConfig {
+	if change.LogLevel != nil {
+		config.LogLevel = strings.ToLower(strings.TrimSpace(*change.LogLevel))
+	}
+	if change.TraceSampleRate != nil {
+		config.TraceSampleRate = *change.TraceSampleRate
+	}
+	if len(change.Metrics) > 0 {
+		disabled := make(map[string]bool, len(config.DisabledMetrics))
+		for _, name := range config.DisabledMetrics {
+			disabled[name] = true
+		}
+		for name, enabled := range change.Metrics {
+			if enabled {
+				delete(disabled, name)
+			} else {
+				disabled[name] = true
+			}
+		}
+		config.DisabledMetrics = make([]string, 0, len(disabled))
+		for name := range disabled {
+			config.DisabledMetrics = append(config.DisabledMetrics, name)
+		}
+		sort.Strings(config.DisabledMetrics)
+	}
+	return config
+}
+
+// currentRuntimeConfig lê a configuração dos componentes; exige h.runtime.mutex
+func (h *HookObservability) currentRuntimeConfig() RuntimeConfig {
+	config := RuntimeConfig{
+		LogLevel:        h.logLevel.Level().String(),
+		TraceSampleRate: h.config.TraceSampleRate,
+		DisabledMetrics: []string{},
+	}
+	if h.sampler != nil {
+		config.TraceSampleRate = h.sampler.rate()
+	}
+	for name, instrument := range h.metricsByName {
+		if instrument.disabled.Load() {
+			config.DisabledMetrics = append(config.DisabledMetrics, name)
+		}
+	}
+	sort.Strings(config.DisabledMetrics)
+	if h.runtime.baseline != nil {
+		revertAt := h.runtime.revertAt
+		config.RevertAt = &revertAt
+	}
+	return config
+}
+
+// applyRuntimeConfig aplica a configuração ao logger, ao sampler e às métricas; exige h.runtime.mutex
+func (h *HookObservability) applyRuntimeConfig(config RuntimeConfig) {
+	if level, err := parseLogLevel(config.LogLevel); err == nil {
+		h.logLevel.SetLevel(level)
+	}
+	if h.sampler != nil {
+		h.sampler.set(config.TraceSampleRate)
+	}
+
+	disabled := make(map[string]bool, len(config.DisabledMetrics))
+	for _, name := range config.DisabledMetrics {
+		disabled[name] = true
+	}
+	for name, instrument := range h.metricsByName {
+		instrument.disabled.Store(disabled[name])
+	}
+}
+
+// scheduleRevert (re)inicia o temporizador da reposição automática; exige h.runtime.mutex
+func (h *HookObservability) scheduleRevert(after time.Duration) {
+	if h.runtime.revertTimer != nil {
+		h.runtime.revertTimer.Stop()
+	}
+	h.runtime.generation++
+	generation := h.runtime.generation
+	h.runtime.revertAt = time.Now().UTC().Add(after)
+	h.runtime.revertTimer = time.AfterFunc(after, func() {
+		h.runtime.mutex.Lock()
+		defer h.runtime.mutex.Unlock()
+
+		// Um temporizador substituído ou parado depois de disparar não repõe nada
+		if h.runtime.closed || h.runtime.baseline == nil || h.runtime.generation != generation {
+			return
+		}
+		h.revertLocked(context.Background(), RuntimeRevertOperator, "reversão automática da alteração temporária")
+	})
+}
+
+// revertLocked repõe a configuração anterior à alteração temporária; exige h.runtime.mutex
+func (h *HookObservability) revertLocked(ctx context.Context, operator, reason string) RuntimeConfig {
+	previous := h.currentRuntimeConfig()
+	baseline := *h.runtime.baseline
+
+	if h.runtime.revertTimer != nil {
+		h.runtime.revertTimer.Stop()
+		h.runtime.revertTimer = nil
+	}
+	h.runtime.generation++
+	h.runtime.baseline = nil
+	h.runtime.revertAt = time.Time{}
+
+	h.applyRuntimeConfig(baseline)
+	current := h.currentRuntimeConfig()
+
+	h.recordRuntimeAudit(ctx, RuntimeConfigAudit{
+		Time:     time.Now().UTC(),
+		Operator: operator,
+		Reason:   reason,
+		Previous: previous,
+		Current:  current,
+		Revert:   true,
+	})
+	return current
+}
+
+// recordRuntimeAudit guarda a alteração no histórico e regista o evento de auditoria; exige h.runtime.mutex
+func (h *HookObservability) recordRuntimeAudit(ctx context.Context, audit RuntimeConfigAudit) {
+	h.runtime.history = append(h.runtime.history, audit)
+	if size := h.historySize(); len(h.runtime.history) > size {
+		h.runtime.history = append([]RuntimeConfigAudit(nil), h.runtime.history[len(h.runtime.history)-size:]...)
+	}
+
+	// O aviso é emitido em qualquer nível de log configurado
+	h.logger.Warn("Configuração de observabilidade alterada em tempo de execução",
+		zap.String("operator", audit.Operator),
+		zap.String("reason", audit.Reason),
+		zap.String("previous_log_level", audit.Previous.LogLevel),
+		zap.String("log_level", audit.Current.LogLevel),
+		zap.Float64("previous_trace_sample_rate", audit.Previous.TraceSampleRate),
+		zap.Float64("trace_sample_rate", audit.Current.TraceSampleRate),
+		zap.Strings("disabled_metrics", audit.Current.DisabledMetrics),
+		zap.String("revert_after", audit.RevertAfter),
+		zap.Bool("revert", audit.Revert),
+	)
+
+	details, err := json.Marshal(audit)
+	if err != nil {
+		details = []byte(audit.Reason)
+	}
+	marketCtx := NewMarketContext(constants.MarketGlobal, "", runtimeAuditHookType)
+	h.TraceAuditEvent(ctx, marketCtx, audit.Operator, runtimeAuditEventType, string(details))
+}
+
+// closeRuntime para a reposição automática pendente quando o adaptador é fechado
+func (h *HookObservability) closeRuntime() {
+	h.runtime.mutex.Lock()
+	defer h.runtime.mutex.Unlock()
+
+	h.runtime.closed = true
+	if h.runtime.revertTimer != nil {
+		h.runtime.revertTimer.Stop()
+		h.runtime.revertTimer = nil
+	}
+}
+
+// maxRevertAfter retorna a duração máxima das alterações temporárias
+func (h *HookObservability) maxRevertAfter() time.Duration {
+	if admin := h.config.RuntimeAdmin; admin != nil && admin.MaxRevertAfter > 0 {
+		return admin.MaxRevertAfter
+	}
+	return DefaultRuntimeMaxRevert
+}
+
+// historySize retorna o número de alterações mantidas no histórico
+func (h *HookObservability) historySize() int {
+	if admin := h.config.RuntimeAdmin; admin != nil && admin.HistorySize > 0 {
+		return admin.HistorySize
+	}
+	return DefaultRuntimeHistorySize
+}
+
+// runtimeAdminPath retorna o caminho do endpoint administrativo
+func (h *HookObservability) runtimeAdminPath() string {
+	if admin := h.config.RuntimeAdmin; admin != nil && admin.Path != "" {
+		return admin.Path
+	}
+	return DefaultRuntimeAdminPath
+}
+
+// runtimeChangeRequest é o corpo dos pedidos de alteração; revert_after usa o formato de
+// time.ParseDuration (ex: 15m)
+type runtimeChangeRequest struct {
+	RuntimeConfigChange
+	RevertAfter string `json:"revert_after,omitempty"`
+}
+
+// runtimeConfigResponse é a resposta do endpoint administrativo
+type runtimeConfigResponse struct {
+	Config  RuntimeConfig        `json:"config"`
+	History []RuntimeConfigAudit `json:"history,omitempty"`
+}
+
+// RuntimeAdminHandler retorna o handler do endpoint administrativo, para montar em servidores
+// próprios; retorna nil sem RuntimeAdmin configurado. Com porta de métricas, o endpoint também
+// é servido no servidor de métricas.
+//
+//	GET    <path>           configuração em vigor e histórico das alterações
+//	PATCH  <path>           aplica uma alteração (JSON com log_level, trace_sample_rate, metrics,
+//	                        revert_after e reason)
+//	DELETE <path>           repõe a configuração anterior à alteração temporária ativa
+func (h *HookObservability) RuntimeAdminHandler() http.Handler {
+	if h.config.RuntimeAdmin == nil {
+		return nil
+	}
+	return http.HandlerFunc(h.serveRuntimeAdmin)
+}
+
+// serveRuntimeAdmin autentica o operador e trata o pedido administrativo
+func (h *HookObservability) serveRuntimeAdmin(w http.ResponseWriter, r *http.Request) {
+	operator, ok := h.authenticateRuntimeAdmin(r)
+	if !ok {
+		h.logger.Warn("Pedido administrativo de observabilidade recusado",
+			zap.String("method", r.Method),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="observability"`)
+		writeRuntimeAdminError(w, http.StatusUnauthorized, "credencial administrativa inválida")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeRuntimeAdminJSON(w, http.StatusOK, runtimeConfigResponse{
+			Config:  h.RuntimeConfig(),
+			History: h.RuntimeConfigHistory(),
+		})
+
+	case http.MethodPatch, http.MethodPut:
+		var req runtimeChangeRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, runtimeAdminMaxBodySize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeRuntimeAdminError(w, http.StatusBadRequest, fmt.Sprintf("corpo do pedido inválido: %v", err))
+			return
+		}
+		change := req.RuntimeConfigChange
+		if req.RevertAfter != "" {
+			revertAfter, err := time.ParseDuration(req.RevertAfter)
+			if err != nil {
+				writeRuntimeAdminError(w, http.StatusBadRequest, fmt.Sprintf("revert_after inválido: %s", req.RevertAfter))
+				return
+			}
+			change.RevertAfter = revertAfter
+		}
+
+		config, err := h.UpdateRuntimeConfig(r.Context(), operator, change)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidRuntimeChange) {
+				status = http.StatusBadRequest
+			}
+			writeRuntimeAdminError(w, status, err.Error())
+			return
+		}
+		writeRuntimeAdminJSON(w, http.StatusOK, runtimeConfigResponse{Config: config})
+
+	case http.MethodDelete:
+		config, err := h.RevertRuntimeConfig(r.Context(), operator, r.URL.Query().Get("reason"))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrNoTemporaryChange) {
+				status = http.StatusConflict
+			}
+			writeRuntimeAdminError(w, status, err.Error())
+			return
+		}
+		writeRuntimeAdminJSON(w, http.StatusOK, runtimeConfigResponse{Config: config})
+
+	default:
+		w.Header().Set("Allow", "GET, PATCH, PUT, DELETE")
+		writeRuntimeAdminError(w, http.StatusMethodNotAllowed, "método não suportado")
+	}
+}
+
+// authenticateRuntimeAdmin identifica o operador pelo token Bearer, comparando todos os tokens
+// em tempo constante
+func (h *HookObservability) authenticateRuntimeAdmin(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	presented := []byte(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+
+	operator := ""
+	for token, candidate := range h.config.RuntimeAdmin.Tokens {
+		if subtle.ConstantTimeCompare(presented, []byte(token)) == 1 {
+			operator = candidate
+		}
+	}
+	return operator, operator != ""
+}
+
+// writeRuntimeAdminJSON escreve a resposta JSON do endpoint administrativo
+func writeRuntimeAdminJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeRuntimeAdminError escreve um erro do endpoint administrativo
+func writeRuntimeAdminError(w http.ResponseWriter, status int, message string) {
+	writeRuntimeAdminJSON(w, status, map[string]string{"error": message})
+}
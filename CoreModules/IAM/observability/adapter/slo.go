@@ -0,0 +1,772 @@
+// Package adapter - SLOs e alertas de burn rate calculados a partir dos histogramas do adaptador
+//
+// Um serviço declara os seus SLOs sobre os histogramas do catálogo (ex.: 99,9% das validações de
+// escopo abaixo de 100ms). O adaptador amostra periodicamente os contadores cumulativos dos seus
+// próprios histogramas, por mercado, e calcula o burn rate do orçamento de erro em várias janelas.
+// Um alerta só fica ativo quando a janela longa e a janela curta excedem o limiar, o que evita
+// alertas por picos já resolvidos. O burn rate, o orçamento restante e os alertas ativos são
+// expostos como métricas, e o estado de cada SLO inclui os exemplars OpenMetrics (trace_id) dos
+// eventos recentes fora do objetivo, para que o operador chegue diretamente aos traces lentos.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Valores padrão dos SLOs
+const (
+	DefaultSLOPath               = "/slo"
+	DefaultSLOEvaluationInterval = 30 * time.Second
+	DefaultSLOPeriod             = 30 * 24 * time.Hour
+
+	// Rótulo do exemplar com o identificador do trace
+	ExemplarTraceIDLabel = "trace_id"
+
+	// Exemplars de eventos fora do objetivo mantidos por SLO e mercado
+	sloMaxExemplars = 5
+
+	// Resolução das amostras anteriores à janela mais longa dos alertas, usadas apenas no
+	// orçamento de erro do período
+	sloCompactionStep = time.Hour
+)
+
+// ErrSLONotConfigured indica que a instância não tem SLOs configurados
+var ErrSLONotConfigured = errors.New("nenhum SLO configurado")
+
+// SLOConfig define os SLOs avaliados pelo adaptador
+type SLOConfig struct {
+	// SLOs declarados pelo serviço
+	Objectives []SLODefinition
+
+	// Intervalo entre amostras dos histogramas (padrão: 30s)
+	EvaluationInterval time.Duration
+
+	// Alertas multi-janela (padrão: DefaultBurnRateAlerts)
+	Alerts []BurnRateAlert
+
+	// Caminho do endpoint de estado no servidor de métricas (padrão: /slo)
+	Path string
+
+	// Relógio das amostras (padrão: time.Now), substituído nos testes
+	Now func() time.Time `json:"-"`
+}
+
+// SLODefinition declara um objetivo sobre um histograma do catálogo: a proporção Objective das
+// observações que passam nos Matchers deve ficar abaixo de Threshold
+type SLODefinition struct {
+	// Identificador do SLO, usado no rótulo slo das métricas (ex: scope-validation-latency)
+	Name        string
+	Description string
+
+	// Histograma do catálogo pelo nome sem o namespace (ex: hook_duration_seconds)
+	Metric string
+
+	// Rótulos que as observações devem ter (ex: operation=validate_scope); o mercado não é
+	// permitido, porque cada mercado é avaliado em separado
+	Matchers map[string]string
+
+	// Limite de um evento bom, na unidade do histograma; deve coincidir com um dos seus buckets
+	Threshold float64
+
+	// Proporção de eventos bons (ex: 0.999)
+	Objective float64
+
+	// Período do orçamento de erro (padrão: 30 dias)
+	Period time.Duration
+}
+
+// BurnRateAlert é um alerta multi-janela: fica ativo quando o burn rate excede BurnRate na
+// janela longa e na janela curta
+type BurnRateAlert struct {
+	Severity    string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	BurnRate    float64
+}
+
+// DefaultBurnRateAlerts retorna os alertas multi-janela padrão para um período de 30 dias:
+// consumo de 2% e 5% do orçamento em 1h e 6h (critical) e de 10% em 1 e 3 dias (warning)
+func DefaultBurnRateAlerts() []BurnRateAlert {
+	return []BurnRateAlert{
+		{Severity: "critical", LongWindow: time.Hour, ShortWindow: 5 * time.Minute, BurnRate: 14.4},
+		{Severity: "critical", LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, BurnRate: 6},
+		{Severity: "warning", LongWindow: 24 * time.Hour, ShortWindow: 2 * time.Hour, BurnRate: 3},
+		{Severity: "warning", LongWindow: 72 * time.Hour, ShortWindow: 6 * time.Hour, BurnRate: 1},
+	}
+}
+
+// HookLatencySLO declara um SLO de latência sobre a duração de uma operação de hook
+// (ex: HookLatencySLO("scope-validation-latency", constants.OperationValidateScope, 100*time.Millisecond, 0.999))
+func HookLatencySLO(name, operation string, threshold time.Duration, objective float64) SLODefinition {
+	return SLODefinition{
+		Name:        name,
+		Description: fmt.Sprintf("%g%% das operações %s abaixo de %s", objective*100, operation, threshold),
+		Metric:      strings.TrimPrefix(MetricHookDurationSeconds, DefaultMetricNamespace+"_"),
+		Matchers:    map[string]string{"operation": operation},
+		Threshold:   threshold.Seconds(),
+		Objective:   objective,
+	}
+}
+
+// Validate valida a configuração dos SLOs
+func (c SLOConfig) Validate() error {
+	if len(c.Objectives) == 0 {
+		return ErrSLONotConfigured
+	}
+	names := make(map[string]bool, len(c.Objectives))
+	for _, slo := range c.Objectives {
+		if err := slo.Validate(); err != nil {
+			return err
+		}
+		if names[slo.Name] {
+			return fmt.Errorf("SLO duplicado: %s", slo.Name)
+		}
+		names[slo.Name] = true
+	}
+	if c.EvaluationInterval < 0 {
+		return fmt.Errorf("intervalo de avaliação dos SLOs inválido: %s", c.EvaluationInterval)
+	}
+	for _, alert := range c.Alerts {
+		if strings.TrimSpace(alert.Severity) == "" {
+			return fmt.Errorf("alerta de burn rate sem severidade")
+		}
+		if alert.ShortWindow <= 0 || alert.LongWindow <= alert.ShortWindow {
+			return fmt.Errorf("janelas do alerta de burn rate %s inválidas: %s/%s", alert.Severity, alert.LongWindow, alert.ShortWindow)
+		}
+		if alert.BurnRate <= 0 {
+			return fmt.Errorf("limiar do alerta de burn rate %s inválido: %g", alert.Severity, alert.BurnRate)
+		}
+	}
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("caminho do endpoint de SLOs inválido: %s", c.Path)
+	}
+	return nil
+}
+
+// Validate valida o SLO contra o catálogo de métricas
+func (d SLODefinition) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return fmt.Errorf("SLO sem nome")
+	}
+	def, ok := LookupMetric(DefaultMetricNamespace + "_" + d.Metric)
+	if !ok {
+		return fmt.Errorf("SLO %s referencia métrica inexistente: %s", d.Name, d.Metric)
+	}
+	if def.Type != MetricTypeHistogram || !def.HasLabel(labelMarket) {
+		return fmt.Errorf("SLO %s requer um histograma com rótulo de mercado: %s", d.Name, d.Metric)
+	}
+	for label := range d.Matchers {
+		if label == labelMarket || !def.HasLabel(label) {
+			return fmt.Errorf("SLO %s com filtro inválido para %s: %s", d.Name, d.Metric, label)
+		}
+	}
+	bucket := false
+	for _, bound := range def.Buckets {
+		if bound == d.Threshold {
+			bucket = true
+			break
+		}
+	}
+	if !bucket {
+		return fmt.Errorf("limite do SLO %s não coincide com um bucket de %s: %g (buckets %v)", d.Name, d.Metric, d.Threshold, def.Buckets)
+	}
+	if d.Objective <= 0 || d.Objective >= 1 {
+		return fmt.Errorf("objetivo do SLO %s inválido: %g, deve estar entre 0 e 1 (exclusivo)", d.Name, d.Objective)
+	}
+	if d.Period < 0 {
+		return fmt.Errorf("período do SLO %s inválido: %s", d.Name, d.Period)
+	}
+	return nil
+}
+
+// period retorna o período do orçamento de erro
+func (d SLODefinition) period() time.Duration {
+	if d.Period > 0 {
+		return d.Period
+	}
+	return DefaultSLOPeriod
+}
+
+// SLOStatus é o estado de um SLO num mercado na última avaliação
+type SLOStatus struct {
+	SLO         string    `json:"slo"`
+	Description string    `json:"description,omitempty"`
+	Market      string    `json:"market"`
+	Objective   float64   `json:"objective"`
+	Threshold   float64   `json:"threshold"`
+	Period      string    `json:"period"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+
+	// Eventos no período do orçamento (ou desde o arranque, se mais recente)
+	TotalEvents float64 `json:"total_events"`
+	BadEvents   float64 `json:"bad_events"`
+
+	// Proporção de eventos bons e do orçamento de erro ainda disponível; o orçamento fica
+	// negativo quando o objetivo já não é cumprido no período
+	Attainment           float64 `json:"attainment"`
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+
+	BurnRates []SLOBurnRate    `json:"burn_rates"`
+	Alerts    []SLOAlertStatus `json:"alerts"`
+	Exemplars []SLOExemplar    `json:"exemplars,omitempty"`
+}
+
+// SLOBurnRate é o burn rate numa janela; Covered é menor que a janela enquanto não há histórico suficiente
+type SLOBurnRate struct {
+	Window   string  `json:"window"`
+	BurnRate float64 `json:"burn_rate"`
+	Covered  string  `json:"covered"`
+}
+
+// SLOAlertStatus é o estado de um alerta multi-janela
+type SLOAlertStatus struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	BurnRate    float64 `json:"burn_rate"`
+	Firing      bool    `json:"firing"`
+}
+
+// SLOExemplar é um evento recente fora do objetivo, com o trace que o originou
+type SLOExemplar struct {
+	TraceID   string    `json:"trace_id"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FiringSeverities retorna as severidades dos alertas ativos, sem repetições
+func (s SLOStatus) FiringSeverities() []string {
+	var severities []string
+	seen := make(map[string]bool)
+	for _, alert := range s.Alerts {
+		if alert.Firing && !seen[alert.Severity] {
+			seen[alert.Severity] = true
+			severities = append(severities, alert.Severity)
+		}
+	}
+	return severities
+}
+
+// sloSample guarda os contadores cumulativos de um SLO num mercado num instante
+type sloSample struct {
+	at    time.Time
+	total float64
+	good  float64
+}
+
+// sloSeries é o histórico de um SLO num mercado
+type sloSeries struct {
+	samples   []sloSample
+	exemplars []SLOExemplar
+	firing    map[string]bool // Por severidade
+	status    SLOStatus
+}
+
+// sloObjective associa um SLO ao histograma da instância
+type sloObjective struct {
+	def       SLODefinition
+	histogram *metricInstrument
+}
+
+// sloTracker amostra os histogramas e calcula o estado dos SLOs
+type sloTracker struct {
+	objectives []sloObjective
+	alerts     []BurnRateAlert
+	windows    []time.Duration // Janelas dos alertas, por ordem crescente
+	interval   time.Duration
+	now        func() time.Time
+	startedAt  time.Time
+
+	burnRate    *metricInstrument
+	budgetLeft  *metricInstrument
+	alertFiring *metricInstrument
+
+	mutex  sync.Mutex
+	series map[string]map[string]*sloSeries // Por SLO e mercado
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// sloMetricDefinitions retorna as métricas dos SLOs com o namespace indicado
+func sloMetricDefinitions(namespace string) []MetricDefinition {
+	return []MetricDefinition{
+		sloBurnRateMetric.WithNamespace(namespace),
+		sloErrorBudgetMetric.WithNamespace(namespace),
+		sloAlertFiringMetric.WithNamespace(namespace),
+	}
+}
+
+// setupSLOs cria o avaliador dos SLOs sobre os histogramas registados e inicia a amostragem
+func (h *HookObservability) setupSLOs(instruments map[string]*metricInstrument) error {
+	config := h.config.SLOs
+	if config == nil {
+		return nil
+	}
+
+	tracker := &sloTracker{
+		alerts:   config.Alerts,
+		interval: config.EvaluationInterval,
+		now:      config.Now,
+		series:   make(map[string]map[string]*sloSeries),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if len(tracker.alerts) == 0 {
+		tracker.alerts = DefaultBurnRateAlerts()
+	}
+	if tracker.interval == 0 {
+		tracker.interval = DefaultSLOEvaluationInterval
+	}
+	if tracker.now == nil {
+		tracker.now = time.Now
+	}
+	tracker.startedAt = tracker.now()
+
+	for _, slo := range config.Objectives {
+		histogram := h.metricsByName[slo.Metric]
+		if histogram == nil {
+			return fmt.Errorf("histograma %s do SLO %s não está registado", slo.Metric, slo.Name)
+		}
+		tracker.objectives = append(tracker.objectives, sloObjective{def: slo, histogram: histogram})
+	}
+
+	seen := make(map[time.Duration]bool)
+	for _, alert := range tracker.alerts {
+		for _, window := range []time.Duration{alert.ShortWindow, alert.LongWindow} {
+			if !seen[window] {
+				seen[window] = true
+				tracker.windows = append(tracker.windows, window)
+			}
+		}
+	}
+	sort.Slice(tracker.windows, func(i, j int) bool { return tracker.windows[i] < tracker.windows[j] })
+
+	defs := sloMetricDefinitions(h.metricNamespace())
+	tracker.burnRate = instruments[defs[0].Name]
+	tracker.budgetLeft = instruments[defs[1].Name]
+	tracker.alertFiring = instruments[defs[2].Name]
+
+	h.slo = tracker
+	go h.runSLOs()
+	return nil
+}
+
+// runSLOs avalia os SLOs a cada intervalo até o adaptador ser fechado
+func (h *HookObservability) runSLOs() {
+	ticker := time.NewTicker(h.slo.interval)
+	defer ticker.Stop()
+	defer close(h.slo.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			h.EvaluateSLOs()
+		case <-h.slo.stop:
+			return
+		}
+	}
+}
+
+// closeSLOs para a amostragem dos SLOs
+func (h *HookObservability) closeSLOs() {
+	if h.slo == nil {
+		return
+	}
+	select {
+	case <-h.slo.stop:
+	default:
+		close(h.slo.stop)
+		<-h.slo.done
+	}
+}
+
+// EvaluateSLOs amostra os histogramas, recalcula o estado de todos os SLOs e atualiza as
+// métricas de burn rate; é chamado a cada EvaluationInterval e pode ser forçado
+func (h *HookObservability) EvaluateSLOs() {
+	t := h.slo
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	at := t.now()
+	for _, objective := range t.objectives {
+		counts := objective.collect()
+		markets := t.series[objective.def.Name]
+		if markets == nil {
+			markets = make(map[string]*sloSeries)
+			t.series[objective.def.Name] = markets
+		}
+		for market, current := range counts {
+			series := markets[market]
+			if series == nil {
+				// Os histogramas da instância começam a zero no arranque
+				series = &sloSeries{
+					samples: []sloSample{{at: t.startedAt}},
+					firing:  make(map[string]bool),
+				}
+				markets[market] = series
+			}
+			series.add(sloSample{at: at, total: current.total, good: current.good},
+				objective.def.period(), t.windows[len(t.windows)-1])
+			series.mergeExemplars(current.exemplars)
+		}
+
+		for market, series := range markets {
+			series.status = t.evaluate(objective.def, market, series, at)
+			h.recordSLOStatus(series)
+		}
+	}
+}
+
+// SLOStatus retorna o estado dos SLOs na última avaliação, ordenado por mercado e SLO;
+// com mercado vazio retorna todos os mercados
+func (h *HookObservability) SLOStatus(market string) []SLOStatus {
+	t := h.slo
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	statuses := make([]SLOStatus, 0)
+	for _, markets := range t.series {
+		for seriesMarket, series := range markets {
+			if market != "" && !strings.EqualFold(seriesMarket, market) {
+				continue
+			}
+			status := series.status
+			status.BurnRates = append([]SLOBurnRate(nil), status.BurnRates...)
+			status.Alerts = append([]SLOAlertStatus(nil), status.Alerts...)
+			status.Exemplars = append([]SLOExemplar(nil), status.Exemplars...)
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Market != statuses[j].Market {
+			return statuses[i].Market < statuses[j].Market
+		}
+		return statuses[i].SLO < statuses[j].SLO
+	})
+	return statuses
+}
+
+// sloCounts são os contadores cumulativos de um SLO num mercado
+type sloCounts struct {
+	total     float64
+	good      float64
+	exemplars []SLOExemplar
+}
+
+// collect lê o histograma e soma, por mercado, as observações que passam nos filtros do SLO;
+// os exemplars dos buckets acima do limite identificam eventos fora do objetivo
+func (o sloObjective) collect() map[string]*sloCounts {
+	counts := make(map[string]*sloCounts)
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		o.histogram.collector.Collect(metrics)
+		close(metrics)
+	}()
+
+	for m := range metrics {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil || pb.Histogram == nil {
+			continue
+		}
+
+		labels := make(map[string]string, len(pb.Label))
+		for _, pair := range pb.Label {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		matches := true
+		for label, value := range o.def.Matchers {
+			if labels[label] != value {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		market := labels[labelMarket]
+		current := counts[market]
+		if current == nil {
+			current = &sloCounts{}
+			counts[market] = current
+		}
+		current.total += float64(pb.Histogram.GetSampleCount())
+		for _, bucket := range pb.Histogram.Bucket {
+			if bucket.GetUpperBound() == o.def.Threshold {
+				current.good += float64(bucket.GetCumulativeCount())
+			}
+			if bucket.GetUpperBound() > o.def.Threshold && bucket.Exemplar != nil {
+				if exemplar, ok := sloExemplarFromDTO(bucket.Exemplar); ok {
+					current.exemplars = append(current.exemplars, exemplar)
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// sloExemplarFromDTO converte um exemplar Prometheus com trace_id
+func sloExemplarFromDTO(exemplar *dto.Exemplar) (SLOExemplar, bool) {
+	for _, pair := range exemplar.Label {
+		if pair.GetName() == ExemplarTraceIDLabel && pair.GetValue() != "" {
+			result := SLOExemplar{TraceID: pair.GetValue(), Value: exemplar.GetValue()}
+			if exemplar.Timestamp != nil {
+				result.Timestamp = exemplar.Timestamp.AsTime()
+			}
+			return result, true
+		}
+	}
+	return SLOExemplar{}, false
+}
+
+// add acrescenta a amostra, descarta as anteriores ao período (mantendo a última como base da
+// janela completa) e compacta para uma por hora as anteriores à janela mais longa dos alertas
+func (s *sloSeries) add(sample sloSample, retention, dense time.Duration) {
+	s.samples = append(s.samples, sample)
+
+	cutoff := sample.at.Add(-retention)
+	drop := 0
+	for drop+1 < len(s.samples) && !s.samples[drop+1].at.After(cutoff) {
+		drop++
+	}
+	samples := s.samples[drop:]
+
+	denseFrom := sample.at.Add(-dense)
+	kept := make([]sloSample, 0, len(samples))
+	var lastStep time.Time
+	for i, current := range samples {
+		step := current.at.Truncate(sloCompactionStep)
+		if i > 0 && current.at.Before(denseFrom) && step.Equal(lastStep) {
+			continue
+		}
+		kept = append(kept, current)
+		lastStep = step
+	}
+	s.samples = kept
+}
+
+// mergeExemplars guarda os exemplars mais recentes, sem repetir traces
+func (s *sloSeries) mergeExemplars(exemplars []SLOExemplar) {
+	for _, exemplar := range exemplars {
+		known := false
+		for _, current := range s.exemplars {
+			if current.TraceID == exemplar.TraceID {
+				known = true
+				break
+			}
+		}
+		if !known {
+			s.exemplars = append(s.exemplars, exemplar)
+		}
+	}
+	sort.SliceStable(s.exemplars, func(i, j int) bool {
+		return s.exemplars[i].Timestamp.After(s.exemplars[j].Timestamp)
+	})
+	if len(s.exemplars) > sloMaxExemplars {
+		s.exemplars = s.exemplars[:sloMaxExemplars]
+	}
+}
+
+// window retorna os eventos e os eventos maus na janela que termina na última amostra, e a
+// duração efetivamente coberta pelo histórico
+func (s *sloSeries) window(d time.Duration) (total, bad float64, covered time.Duration) {
+	last := s.samples[len(s.samples)-1]
+	start := last.at.Add(-d)
+	base := s.samples[0]
+	for _, sample := range s.samples {
+		if sample.at.After(start) {
+			break
+		}
+		base = sample
+	}
+
+	total = last.total - base.total
+	good := last.good - base.good
+	if total < 0 || good < 0 {
+		// Histograma recriado: os contadores recomeçaram a zero
+		total, good = last.total, last.good
+	}
+	return total, total - good, last.at.Sub(base.at)
+}
+
+// burnRate calcula o burn rate: a proporção de eventos maus dividida pelo orçamento de erro
+func burnRate(total, bad, objective float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return (bad / total) / (1 - objective)
+}
+
+// evaluate calcula o estado do SLO no mercado a partir do histórico
+func (t *sloTracker) evaluate(slo SLODefinition, market string, series *sloSeries, at time.Time) SLOStatus {
+	status := SLOStatus{
+		SLO:         slo.Name,
+		Description: slo.Description,
+		Market:      market,
+		Objective:   slo.Objective,
+		Threshold:   slo.Threshold,
+		Period:      formatSLOWindow(slo.period()),
+		EvaluatedAt: at,
+		Attainment:  1,
+		Exemplars:   append([]SLOExemplar(nil), series.exemplars...),
+	}
+
+	total, bad, _ := series.window(slo.period())
+	status.TotalEvents = total
+	status.BadEvents = bad
+	if total > 0 {
+		status.Attainment = (total - bad) / total
+	}
+	status.ErrorBudgetRemaining = 1 - burnRate(total, bad, slo.Objective)
+
+	rates := make(map[time.Duration]float64, len(t.windows))
+	for _, window := range t.windows {
+		total, bad, covered := series.window(window)
+		rates[window] = burnRate(total, bad, slo.Objective)
+		status.BurnRates = append(status.BurnRates, SLOBurnRate{
+			Window:   formatSLOWindow(window),
+			BurnRate: rates[window],
+			Covered:  formatSLOWindow(covered),
+		})
+	}
+
+	for _, alert := range t.alerts {
+		status.Alerts = append(status.Alerts, SLOAlertStatus{
+			Severity:    alert.Severity,
+			LongWindow:  formatSLOWindow(alert.LongWindow),
+			ShortWindow: formatSLOWindow(alert.ShortWindow),
+			BurnRate:    alert.BurnRate,
+			Firing:      rates[alert.LongWindow] >= alert.BurnRate && rates[alert.ShortWindow] >= alert.BurnRate,
+		})
+	}
+	return status
+}
+
+// recordSLOStatus atualiza as métricas do SLO e regista as ativações e resoluções dos alertas,
+// com os traces dos eventos fora do objetivo
+func (h *HookObservability) recordSLOStatus(series *sloSeries) {
+	t := h.slo
+	status := series.status
+
+	for _, rate := range status.BurnRates {
+		t.burnRate.record(rate.BurnRate, status.Market, status.SLO, rate.Window)
+	}
+	t.budgetLeft.record(status.ErrorBudgetRemaining, status.Market, status.SLO)
+
+	firing := make(map[string]bool)
+	for _, alert := range status.Alerts {
+		firing[alert.Severity] = firing[alert.Severity] || alert.Firing
+	}
+	for severity, active := range firing {
+		value := 0.0
+		if active {
+			value = 1
+		}
+		t.alertFiring.record(value, status.Market, status.SLO, severity)
+
+		if active == series.firing[severity] {
+			continue
+		}
+		series.firing[severity] = active
+		fields := []zap.Field{
+			zap.String("slo", status.SLO),
+			zap.String("market", status.Market),
+			zap.String("severity", severity),
+			zap.Float64("error_budget_remaining", status.ErrorBudgetRemaining),
+		}
+		if active {
+			traceIDs := make([]string, 0, len(status.Exemplars))
+			for _, exemplar := range status.Exemplars {
+				traceIDs = append(traceIDs, exemplar.TraceID)
+			}
+			h.logger.Warn("Alerta de burn rate do SLO ativo",
+				append(fields, zap.Any("burn_rates", status.BurnRates), zap.Strings("trace_ids", traceIDs))...)
+		} else {
+			h.logger.Info("Alerta de burn rate do SLO resolvido", fields...)
+		}
+	}
+}
+
+// formatSLOWindow formata uma janela em dias, horas ou minutos quando exata (ex: 5m, 6h, 3d)
+func formatSLOWindow(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "0s"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// traceExemplar retorna o exemplar com o trace_id do span, ou nil quando o span não é amostrado
+func traceExemplar(span trace.Span) prometheus.Labels {
+	spanCtx := span.SpanContext()
+	if !spanCtx.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{ExemplarTraceIDLabel: spanCtx.TraceID().String()}
+}
+
+// sloPath retorna o caminho do endpoint de estado dos SLOs
+func (h *HookObservability) sloPath() string {
+	if config := h.config.SLOs; config != nil && config.Path != "" {
+		return config.Path
+	}
+	return DefaultSLOPath
+}
+
+// sloStatusResponse é a resposta do endpoint de estado dos SLOs
+type sloStatusResponse struct {
+	Service string      `json:"service"`
+	SLOs    []SLOStatus `json:"slos"`
+}
+
+// SLOHandler retorna o handler do estado dos SLOs, para montar em servidores próprios; retorna
+// nil sem SLOs configurados. Com porta de métricas, o endpoint também é servido no servidor de
+// métricas.
+//
+//	GET <path>[?market=<mercado>]   estado dos SLOs na última avaliação
+func (h *HookObservability) SLOHandler() http.Handler {
+	if h.config.SLOs == nil {
+		return nil
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeRuntimeAdminError(w, http.StatusMethodNotAllowed, "método não suportado")
+			return
+		}
+		writeRuntimeAdminJSON(w, http.StatusOK, sloStatusResponse{
+			Service: h.config.ServiceName,
+			SLOs:    h.SLOStatus(r.URL.Query().Get("market")),
+		})
+	})
+}
@@ -0,0 +1,342 @@
+// Package adapter fornece redação de spans para o adaptador de observabilidade MCP-IAM
+//
+// Este arquivo define a camada aplicada aos spans imediatamente antes da exportação: lista de
+// atributos permitidos, pseudonimização por HMAC e truncagem por mercado, garantindo que
+// identificadores de utilizadores, documentos e escopos não saem do processo em texto claro.
+//
+// Conformidades: GDPR (Art. 4(5) e 25), LGPD, Lei 22/11 (Angola), ISO/IEC 27701
+package adapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/innovabiz/iam/constants"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// AttributeAction define o tratamento de um atributo de span antes da exportação
+type AttributeAction string
+
+const (
+	// AttributeKeep exporta o atributo apenas com a redação de PII padrão
+	AttributeKeep AttributeAction = "keep"
+	// AttributeHash substitui o valor por um pseudónimo HMAC-SHA256 estável
+	AttributeHash AttributeAction = "hash"
+	// AttributeTruncate mantém apenas o prefixo do valor
+	AttributeTruncate AttributeAction = "truncate"
+	// AttributeDrop remove o atributo do span exportado
+	AttributeDrop AttributeAction = "drop"
+)
+
+const (
+	// Prefixo dos valores pseudonimizados, para distinção de valores originais
+	pseudonymPrefix = "pseud:"
+
+	// Número de caracteres hexadecimais do HMAC mantidos no pseudónimo
+	pseudonymLength = 16
+
+	// Comprimento mínimo da chave de pseudonimização
+	minPseudonymizationKeyLength = 16
+
+	// DefaultTruncateLength é o número de caracteres mantidos pela truncagem quando a regra não o define
+	DefaultTruncateLength = 8
+)
+
+// defaultAllowedSpanAttributes lista os atributos emitidos pelo próprio adaptador
+var defaultAllowedSpanAttributes = []string{
+	"market",
+	"tenant_type",
+	"hook_type",
+	"operation",
+	"user_id",
+	"description",
+	"details",
+	"event_category",
+	"event_details",
+	"event_type",
+	"mfa_level",
+	"scope",
+	"severity",
+	"token_id",
+	"exception.type",
+	"exception.message",
+}
+
+// MarketRedactionRule define as ações aplicadas aos atributos dos spans de um mercado
+type MarketRedactionRule struct {
+	// Ação por chave de atributo; chaves ausentes são mantidas
+	Attributes map[string]AttributeAction
+
+	// Número de caracteres mantidos pela truncagem (DefaultTruncateLength quando zero)
+	TruncateLength int
+}
+
+// SpanRedactionConfig define a redação aplicada aos spans antes da exportação
+type SpanRedactionConfig struct {
+	// Atributos exportados; os restantes são removidos. Aceita prefixos terminados em ".*"
+	AllowedAttributes []string
+
+	// Regra aplicada aos mercados sem regra específica
+	DefaultRule MarketRedactionRule
+
+	// Regras por mercado, indexadas pelo atributo "market" do span
+	MarketRules map[string]MarketRedactionRule
+
+	// Chave HMAC da pseudonimização. Sem chave, é gerada uma chave aleatória por processo
+	// e os pseudónimos deixam de ser correlacionáveis entre reinícios
+	PseudonymizationKey []byte `json:"-"`
+}
+
+// DefaultSpanRedactionConfig retorna a redação padrão de spans
+//
+// Na UE os identificadores são pseudonimizados (GDPR Art. 4(5)), a descrição, que repete o
+// escopo, é removida e os detalhes são truncados;
+// no Brasil os identificadores de utilizador e token são pseudonimizados (LGPD Art. 13);
+// nos restantes mercados o identificador do utilizador é truncado.
+func DefaultSpanRedactionConfig() SpanRedactionConfig {
+	return SpanRedactionConfig{
+		AllowedAttributes: append([]string(nil), defaultAllowedSpanAttributes...),
+		DefaultRule: MarketRedactionRule{
+			Attributes: map[string]AttributeAction{
+				"user_id": AttributeTruncate,
+			},
+		},
+		MarketRules: map[string]MarketRedactionRule{
+			constants.MarketEU: {
+				Attributes: map[string]AttributeAction{
+					"user_id":       AttributeHash,
+					"scope":         AttributeHash,
+					"token_id":      AttributeHash,
+					"description":   AttributeDrop,
+					"details":       AttributeTruncate,
+					"event_details": AttributeTruncate,
+				},
+				TruncateLength: 32,
+			},
+			constants.MarketBrazil: {
+				Attributes: map[string]AttributeAction{
+					"user_id":  AttributeHash,
+					"token_id": AttributeHash,
+				},
+			},
+		},
+	}
+}
+
+// Validate valida a configuração de redação de spans
+func (c *SpanRedactionConfig) Validate() error {
+	if len(c.PseudonymizationKey) > 0 && len(c.PseudonymizationKey) < minPseudonymizationKeyLength {
+		return fmt.Errorf("chave de pseudonimização deve ter pelo menos %d bytes", minPseudonymizationKeyLength)
+	}
+
+	rules := map[string]MarketRedactionRule{"default": c.DefaultRule}
+	for market, rule := range c.MarketRules {
+		rules[market] = rule
+	}
+	for market, rule := range rules {
+		if rule.TruncateLength < 0 {
+			return fmt.Errorf("comprimento de truncagem inválido para o mercado %s: %d", market, rule.TruncateLength)
+		}
+		for key, action := range rule.Attributes {
+			switch action {
+			case AttributeKeep, AttributeHash, AttributeTruncate, AttributeDrop:
+			default:
+				return fmt.Errorf("ação de redação inválida para o atributo %s no mercado %s: %s", key, market, action)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SpanRedactor aplica a lista de atributos permitidos e as regras por mercado aos spans
+type SpanRedactor struct {
+	allowed       map[string]bool
+	allowedPrefix []string
+	defaultRule   MarketRedactionRule
+	marketRules   map[string]MarketRedactionRule
+	key           []byte
+	pii           *PIIRedactor
+}
+
+// NewSpanRedactor cria um redator de spans; pii aplica a redação padrão aos valores mantidos
+func NewSpanRedactor(config SpanRedactionConfig, pii *PIIRedactor) (*SpanRedactor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	key := config.PseudonymizationKey
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("falha ao gerar chave de pseudonimização: %w", err)
+		}
+	}
+	if pii == nil {
+		pii = NewPIIRedactor()
+	}
+
+	r := &SpanRedactor{
+		allowed:     make(map[string]bool),
+		defaultRule: config.DefaultRule,
+		marketRules: make(map[string]MarketRedactionRule),
+		key:         key,
+		pii:         pii,
+	}
+	for _, attr := range config.AllowedAttributes {
+		if strings.HasSuffix(attr, ".*") {
+			r.allowedPrefix = append(r.allowedPrefix, strings.TrimSuffix(attr, "*"))
+			continue
+		}
+		r.allowed[attr] = true
+	}
+	for market, rule := range config.MarketRules {
+		r.marketRules[strings.ToLower(market)] = rule
+	}
+
+	return r, nil
+}
+
+// IsAllowed verifica se o atributo pode ser exportado
+func (r *SpanRedactor) IsAllowed(key string) bool {
+	if r.allowed[key] {
+		return true
+	}
+	for _, prefix := range r.allowedPrefix {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pseudonymize retorna o pseudónimo HMAC-SHA256 estável de um valor
+func (r *SpanRedactor) Pseudonymize(value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:pseudonymLength]
+}
+
+// RedactAttributes retorna os atributos exportáveis de um span do mercado indicado
+func (r *SpanRedactor) RedactAttributes(market string, attrs []attribute.KeyValue) []attribute.KeyValue {
+	rule := r.ruleFor(market)
+	redacted := make([]attribute.KeyValue, 0, len(attrs))
+
+	for _, attr := range attrs {
+		key := string(attr.Key)
+		if !r.IsAllowed(key) {
+			continue
+		}
+
+		switch rule.Attributes[key] {
+		case AttributeDrop:
+			continue
+		case AttributeHash:
+			if attr.Value.Type() == attribute.STRING && attr.Value.AsString() == "" {
+				redacted = append(redacted, attr)
+				continue
+			}
+			redacted = append(redacted, attribute.String(key, r.Pseudonymize(attr.Value.Emit())))
+			continue
+		case AttributeTruncate:
+			if attr.Value.Type() == attribute.STRING {
+				attr = attribute.String(key, truncateValue(attr.Value.AsString(), rule.TruncateLength))
+			}
+		}
+
+		redacted = append(redacted, r.pii.RedactAttributes([]attribute.KeyValue{attr})...)
+	}
+
+	return redacted
+}
+
+// ruleFor retorna a regra do mercado ou a regra padrão
+func (r *SpanRedactor) ruleFor(market string) MarketRedactionRule {
+	if rule, ok := r.marketRules[strings.ToLower(market)]; ok {
+		return rule
+	}
+	return r.defaultRule
+}
+
+// redactSpan retorna uma vista do span com atributos, eventos e estado redigidos
+func (r *SpanRedactor) redactSpan(span sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	var market string
+	for _, attr := range span.Attributes() {
+		if attr.Key == "market" {
+			market = attr.Value.AsString()
+			break
+		}
+	}
+
+	events := make([]sdktrace.Event, len(span.Events()))
+	for i, evt := range span.Events() {
+		evt.Attributes = r.RedactAttributes(market, evt.Attributes)
+		events[i] = evt
+	}
+
+	status := span.Status()
+	status.Description = r.pii.RedactString(status.Description)
+
+	return &redactedSpan{
+		ReadOnlySpan: span,
+		attributes:   r.RedactAttributes(market, span.Attributes()),
+		events:       events,
+		status:       status,
+	}
+}
+
+// WrapExporter envolve um exportador para redigir os spans antes da exportação
+func (r *SpanRedactor) WrapExporter(exporter sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return &redactingSpanExporter{next: exporter, redactor: r}
+}
+
+// truncateValue mantém os primeiros caracteres do valor
+func truncateValue(value string, length int) string {
+	if length <= 0 {
+		length = DefaultTruncateLength
+	}
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length]) + "…"
+}
+
+// redactedSpan substitui os dados sensíveis de um span já terminado
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdktrace.Event
+	status     sdktrace.Status
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue { return s.attributes }
+func (s *redactedSpan) Events() []sdktrace.Event         { return s.events }
+func (s *redactedSpan) Status() sdktrace.Status          { return s.status }
+
+// redactingSpanExporter é um sdktrace.SpanExporter que aplica a redação antes de delegar
+type redactingSpanExporter struct {
+	next     sdktrace.SpanExporter
+	redactor *SpanRedactor
+}
+
+// ExportSpans redige e exporta os spans
+func (e *redactingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		redacted[i] = e.redactor.redactSpan(span)
+	}
+	return e.next.ExportSpans(ctx, redacted)
+}
+
+// Shutdown finaliza o exportador envolvido
+func (e *redactingSpanExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
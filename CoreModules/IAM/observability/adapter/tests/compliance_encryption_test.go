@@ -0,0 +1,234 @@
+// Package tests fornece testes unitários para a cifra por tenant dos logs de compliance
+//
+// Estes testes validam a cifra de envelope dos eventos, a rotação de chaves sem recifra
+// do histórico e o isolamento entre tenants.
+//
+// Conformidades: ISO/IEC 27001 (A.8.24), PCI-DSS 3.5, LGPD, GDPR
+package tests
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComplianceLogCipherRotation valida que eventos anteriores à rotação continuam legíveis
+func TestComplianceLogCipherRotation(t *testing.T) {
+	ctx := context.Background()
+	provider := adapter.NewFileKeyProvider(t.TempDir())
+
+	first, err := provider.Rotate(ctx, "banco-x")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", first.Version)
+
+	logCipher := adapter.NewComplianceLogCipher(provider)
+	before, err := logCipher.Encrypt(ctx, "banco-x", []byte("evento antes da rotação"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", before.KeyVersion)
+	assert.NotContains(t, before.Ciphertext, "evento")
+
+	second, err := provider.Rotate(ctx, "banco-x")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", second.Version)
+
+	// Uma nova cifra obtém a versão ativa sem aguardar o intervalo de verificação
+	rotated := adapter.NewComplianceLogCipher(provider)
+	after, err := rotated.Encrypt(ctx, "banco-x", []byte("evento após a rotação"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", after.KeyVersion)
+
+	reader := adapter.NewComplianceLogCipher(provider)
+	plaintext, err := reader.Decrypt(ctx, before)
+	require.NoError(t, err)
+	assert.Equal(t, "evento antes da rotação", string(plaintext))
+
+	plaintext, err = reader.Decrypt(ctx, after)
+	require.NoError(t, err)
+	assert.Equal(t, "evento após a rotação", string(plaintext))
+
+	versions, err := provider.Versions("banco-x")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1", "v2"}, versions)
+}
+
+// TestComplianceLogCipherTenantIsolation valida que um registo não é decifrável com a chave de outro tenant
+func TestComplianceLogCipherTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	provider := adapter.NewFileKeyProvider(t.TempDir())
+	_, err := provider.Rotate(ctx, "banco-x")
+	require.NoError(t, err)
+	_, err = provider.Rotate(ctx, "banco-y")
+	require.NoError(t, err)
+
+	record, err := adapter.NewComplianceLogCipher(provider).Encrypt(ctx, "banco-x", []byte("segredo"))
+	require.NoError(t, err)
+
+	record.Tenant = "banco-y"
+	_, err = adapter.NewComplianceLogCipher(provider).Decrypt(ctx, record)
+	assert.ErrorIs(t, err, adapter.ErrInvalidComplianceRecord)
+
+	_, err = adapter.NewComplianceLogCipher(provider).Encrypt(ctx, "banco-z", []byte("sem chave"))
+	assert.ErrorIs(t, err, adapter.ErrComplianceKeyNotFound)
+
+	_, err = provider.Rotate(ctx, "../banco-x")
+	assert.ErrorIs(t, err, adapter.ErrInvalidComplianceTenant)
+}
+
+// TestFileKeyProviderAuthorizeOperator valida a lista de operadores autorizados por tenant
+func TestFileKeyProviderAuthorizeOperator(t *testing.T) {
+	ctx := context.Background()
+	keysDir := t.TempDir()
+	provider := adapter.NewFileKeyProvider(keysDir)
+	_, err := provider.Rotate(ctx, "banco-x")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, provider.AuthorizeOperator(ctx, "banco-x", "ana.silva"), adapter.ErrComplianceOperatorNotAuthorized)
+
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "banco-x", "operators"), []byte("ana.silva\njoao.santos\n"), 0600))
+	assert.NoError(t, provider.AuthorizeOperator(ctx, "banco-x", "ana.silva"))
+	assert.ErrorIs(t, provider.AuthorizeOperator(ctx, "banco-x", "carlos"), adapter.ErrComplianceOperatorNotAuthorized)
+	assert.ErrorIs(t, provider.AuthorizeOperator(ctx, "banco-x", ""), adapter.ErrComplianceOperatorNotAuthorized)
+}
+
+// TestEncryptedComplianceAuditLog valida que o adaptador grava eventos cifrados no diretório do tenant
+func TestEncryptedComplianceAuditLog(t *testing.T) {
+	ctx := context.Background()
+	logsDir := t.TempDir()
+	provider := adapter.NewFileKeyProvider(t.TempDir())
+	_, err := provider.Rotate(ctx, "banco-x")
+	require.NoError(t, err)
+
+	config := adapter.Config{
+		Environment:           "development",
+		ServiceName:           "test-service",
+		ComplianceLogsPath:    logsDir,
+		EnableComplianceAudit: true,
+		LogLevel:              "info",
+	}
+	config.WithComplianceEncryption("", provider)
+
+	obs, err := adapter.NewHookObservability(config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, constants.TenantFinancial, constants.HookTypePrivilegeElevation).
+		WithMetadata(adapter.MetadataTenantID, "banco-x")
+	obs.TraceAuditEvent(ctx, marketCtx, "user-123", "elevation_approved", "Elevação aprovada pelo supervisor")
+
+	files, err := filepath.Glob(filepath.Join(logsDir, constants.MarketAngola, "banco-x", "*-audit-events.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	require.True(t, scanner.Scan())
+	line := scanner.Text()
+	assert.NotContains(t, line, "Elevação aprovada")
+
+	record, encrypted := adapter.ParseComplianceRecord(line)
+	require.True(t, encrypted)
+	assert.Equal(t, "banco-x", record.Tenant)
+
+	plaintext, err := adapter.NewComplianceLogCipher(provider).Decrypt(ctx, record)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(plaintext), "Elevação aprovada pelo supervisor"))
+	assert.Contains(t, string(plaintext), "[elevation_approved]")
+}
+
+// TestComplianceEventQuarantinedWhenEncryptionFails valida que um evento sem chave do tenant
+// fica em quarentena e é gravado cifrado quando a chave volta a estar disponível
+func TestComplianceEventQuarantinedWhenEncryptionFails(t *testing.T) {
+	ctx := context.Background()
+	logsDir := t.TempDir()
+	provider := adapter.NewFileKeyProvider(t.TempDir())
+
+	config := adapter.Config{
+		Environment:           "development",
+		ServiceName:           "test-service",
+		ComplianceLogsPath:    logsDir,
+		EnableComplianceAudit: true,
+		LogLevel:              "info",
+	}
+	config.WithComplianceEncryption("", provider)
+
+	obs, err := adapter.NewHookObservability(config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, constants.TenantFinancial, constants.HookTypePrivilegeElevation).
+		WithMetadata(adapter.MetadataTenantID, "banco-z")
+	obs.TraceAuditEvent(ctx, marketCtx, "user-123", "elevation_approved", "Elevação aprovada sem chave")
+
+	logs, err := filepath.Glob(filepath.Join(logsDir, constants.MarketAngola, "banco-z", "*-audit-events.log"))
+	require.NoError(t, err)
+	assert.Empty(t, logs, "o evento não pode ser gravado sem cifra")
+
+	quarantineDir := adapter.DefaultComplianceQuarantinePath(logsDir)
+	info, err := os.Stat(filepath.Join(quarantineDir, "banco-z.jsonl"))
+	require.NoError(t, err, "o evento deve ficar em quarentena")
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = provider.Rotate(ctx, "banco-z")
+	require.NoError(t, err)
+
+	logCipher := adapter.NewComplianceLogCipher(provider)
+	replayed, err := adapter.NewFileComplianceQuarantine(quarantineDir).Replay(ctx, logCipher, logsDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	remaining, err := filepath.Glob(filepath.Join(quarantineDir, "*"))
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	logs, err = filepath.Glob(filepath.Join(logsDir, constants.MarketAngola, "banco-z", "*-audit-events.log"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	data, err := os.ReadFile(logs[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Elevação aprovada")
+
+	record, encrypted := adapter.ParseComplianceRecord(strings.TrimSpace(string(data)))
+	require.True(t, encrypted)
+	plaintext, err := logCipher.Decrypt(ctx, record)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(plaintext), "Elevação aprovada sem chave"))
+}
+
+// TestComplianceQuarantineReplayKeepsFailedEvents valida que os eventos ainda sem chave permanecem em quarentena
+func TestComplianceQuarantineReplayKeepsFailedEvents(t *testing.T) {
+	ctx := context.Background()
+	logsDir := t.TempDir()
+	quarantine := adapter.NewFileComplianceQuarantine(filepath.Join(t.TempDir(), "quarantine"))
+
+	require.NoError(t, quarantine.Quarantine(ctx, &adapter.QuarantinedComplianceEvent{
+		Market:   constants.MarketAngola,
+		Tenant:   "banco-z",
+		Category: "security",
+		Line:     "[2025-01-01T10:00:00Z] [AO] [security] [user-1] [login_failed]: tentativa",
+		Reason:   "chave indisponível",
+	}))
+	assert.Error(t, quarantine.Quarantine(ctx, &adapter.QuarantinedComplianceEvent{Tenant: "../banco-z"}))
+
+	provider := adapter.NewFileKeyProvider(t.TempDir())
+	replayed, err := quarantine.Replay(ctx, adapter.NewComplianceLogCipher(provider), logsDir)
+	assert.ErrorIs(t, err, adapter.ErrComplianceKeyNotFound)
+	assert.Equal(t, 0, replayed)
+
+	_, err = provider.Rotate(ctx, "banco-z")
+	require.NoError(t, err)
+	replayed, err = quarantine.Replay(ctx, adapter.NewComplianceLogCipher(provider), logsDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+}
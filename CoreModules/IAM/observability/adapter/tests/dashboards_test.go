@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestGenerateGrafanaDashboard valida que o dashboard cobre todas as métricas do catálogo
+func TestGenerateGrafanaDashboard(t *testing.T) {
+	data, err := adapter.GenerateGrafanaDashboard(adapter.DashboardOptions{Market: constants.MarketAngola})
+	require.NoError(t, err)
+
+	var dashboard struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Type    string `json:"type"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(data, &dashboard))
+	assert.Equal(t, "innovabiz-iam-obs-angola", dashboard.UID)
+
+	var exprs []string
+	for _, panel := range dashboard.Panels {
+		for _, target := range panel.Targets {
+			exprs = append(exprs, target.Expr)
+		}
+	}
+	all := strings.Join(exprs, "\n")
+
+	for _, def := range adapter.MetricCatalog() {
+		assert.Contains(t, all, def.Name, "métrica %s sem painel", def.Name)
+	}
+	assert.Contains(t, all, `histogram_quantile(0.95, sum by (le, tenant_type, hook_type, operation) (rate(innovabiz_iam_hook_duration_seconds_bucket{market="Angola", tenant_type=~"$tenant_type"}[5m])))`)
+	assert.Contains(t, all, `sum by (hook_type) (innovabiz_iam_test_coverage_percent)`)
+
+	_, err = adapter.GenerateGrafanaDashboard(adapter.DashboardOptions{})
+	assert.Error(t, err, "mercado é obrigatório")
+}
+
+// TestGeneratePrometheusRules valida as regras de alerta geradas por mercado
+func TestGeneratePrometheusRules(t *testing.T) {
+	data, err := adapter.GeneratePrometheusRules(constants.MarketBrazil, adapter.DefaultAlertThresholds())
+	require.NoError(t, err)
+
+	var rules adapter.PrometheusRuleFile
+	require.NoError(t, yaml.Unmarshal(data, &rules))
+	require.Len(t, rules.Groups, 1)
+	assert.Equal(t, "innovabiz-iam-brazil", rules.Groups[0].Name)
+
+	alerts := make(map[string]adapter.PrometheusRule)
+	for _, rule := range rules.Groups[0].Rules {
+		alerts[rule.Alert] = rule
+		assert.Contains(t, rule.Expr, `market="Brazil"`)
+		assert.Equal(t, constants.MarketBrazil, rule.Labels["market"])
+	}
+	require.Contains(t, alerts, "IAMHookLatencyHigh")
+	assert.True(t, strings.HasSuffix(alerts["IAMHookLatencyHigh"].Expr, "> 1"))
+	require.Contains(t, alerts, "IAMMFAFailureRatioHigh")
+	assert.Contains(t, alerts["IAMMFAFailureRatioHigh"].Expr, `result="failure"`)
+}
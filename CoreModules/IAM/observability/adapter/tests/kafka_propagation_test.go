@@ -0,0 +1,142 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam a propagação do contexto de trace W3C e do baggage nos cabeçalhos
+// das mensagens Kafka, do span de publicação até ao span de processamento do consumidor.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useSpanRecorder regista um TracerProvider global com gravação de spans durante o teste
+func useSpanRecorder(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return provider, recorder
+}
+
+// headerValues retorna os valores de todas as ocorrências da chave
+func headerValues(headers []kafka.Header, key string) []string {
+	var values []string
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, key) {
+			values = append(values, string(header.Value))
+		}
+	}
+	return values
+}
+
+func TestInjectKafkaHeadersPropagatesTraceAndBaggage(t *testing.T) {
+	provider, _ := useSpanRecorder(t)
+
+	member, err := baggage.NewMember("tenant.id", "tenant-ao")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	ctx, span := provider.Tracer("test").Start(ctx, "publish")
+	defer span.End()
+
+	headers := adapter.InjectKafkaHeaders(ctx, []kafka.Header{
+		{Key: "event_type", Value: []byte("role.assigned")},
+	})
+
+	assert.Equal(t, []string{"role.assigned"}, headerValues(headers, "event_type"), "os cabeçalhos existentes devem ser mantidos")
+	traceparent := headerValues(headers, adapter.KafkaHeaderTraceparent)
+	require.Len(t, traceparent, 1)
+	assert.Contains(t, traceparent[0], span.SpanContext().TraceID().String())
+	assert.Contains(t, traceparent[0], span.SpanContext().SpanID().String())
+	assert.Equal(t, []string{"tenant.id=tenant-ao"}, headerValues(headers, adapter.KafkaHeaderBaggage))
+
+	extracted := adapter.ExtractKafkaHeaders(context.Background(), headers)
+	remote := trace.SpanContextFromContext(extracted)
+	assert.True(t, remote.IsValid())
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+	assert.Equal(t, "tenant-ao", baggage.FromContext(extracted).Member("tenant.id").Value())
+}
+
+func TestInjectKafkaHeadersReplacesForwardedContext(t *testing.T) {
+	provider, _ := useSpanRecorder(t)
+
+	stale := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	original := []kafka.Header{
+		{Key: "Traceparent", Value: []byte(stale)},
+		{Key: "tenant_id", Value: []byte("tenant-ao")},
+	}
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "forward")
+	defer span.End()
+
+	headers := adapter.InjectKafkaHeaders(ctx, original)
+
+	traceparent := headerValues(headers, adapter.KafkaHeaderTraceparent)
+	require.Len(t, traceparent, 1, "o contexto do produtor anterior deve ser substituído")
+	assert.NotEqual(t, stale, traceparent[0])
+	assert.Equal(t, []string{"tenant-ao"}, headerValues(headers, "tenant_id"))
+	assert.Equal(t, stale, string(original[0].Value), "a mensagem de origem não deve ser alterada")
+}
+
+func TestExtractKafkaHeadersWithoutPropagation(t *testing.T) {
+	ctx := adapter.ExtractKafkaHeaders(context.Background(), []kafka.Header{
+		{Key: "event_type", Value: []byte("role.assigned")},
+	})
+
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+	assert.Zero(t, baggage.FromContext(ctx).Len())
+}
+
+func TestStartKafkaConsumerSpanContinuesProducerTrace(t *testing.T) {
+	provider, recorder := useSpanRecorder(t)
+
+	ctx, producer := provider.Tracer("test").Start(context.Background(), "publish")
+	msg := kafka.Message{
+		Topic:     "iam.role.assigned",
+		Partition: 3,
+		Offset:    42,
+		Headers:   adapter.InjectKafkaHeaders(ctx, nil),
+	}
+	producer.End()
+
+	_, consumer := adapter.StartKafkaConsumerSpan(context.Background(), msg)
+	consumer.End()
+
+	var processed sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "iam.role.assigned process" {
+			processed = span
+		}
+	}
+	require.NotNil(t, processed)
+	assert.Equal(t, trace.SpanKindConsumer, processed.SpanKind())
+	assert.Equal(t, producer.SpanContext().TraceID(), processed.SpanContext().TraceID())
+	assert.Equal(t, producer.SpanContext().SpanID(), processed.Parent().SpanID())
+	assert.Contains(t, processed.Attributes(), attribute.String("messaging.destination", "iam.role.assigned"))
+	assert.Contains(t, processed.Attributes(), attribute.Int("messaging.kafka.partition", 3))
+	assert.Contains(t, processed.Attributes(), attribute.Int64("messaging.kafka.offset", 42))
+}
@@ -0,0 +1,192 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam os orçamentos de latência das operações de hook: a métrica e o evento de
+// segurança emitidos quando uma operação excede o orçamento, a precedência dos orçamentos por tipo
+// de hook, o cancelamento opcional do contexto e a validação da configuração.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// gatheredCounter retorna o valor do contador com os rótulos indicados
+func gatheredCounter(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.Metric {
+			if metricHasLabels(m, labels) {
+				total += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+// TestLatencyBudgetExceeded verifica a métrica, o evento de segurança e o span das operações
+// que excedem o orçamento de latência
+func TestLatencyBudgetExceeded(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter := &captureExporter{}
+	config := &adapter.Config{
+		Environment:     "test",
+		ServiceName:     "test-service",
+		LogLevel:        "error",
+		MetricsRegistry: registry,
+		SpanExporter:    exporter,
+	}
+	config.WithLatencyBudgets(adapter.LatencyBudgetConfig{
+		Budgets: []adapter.LatencyBudget{
+			adapter.HookLatencyBudget(constants.OperationValidateScope, 5*time.Millisecond),
+		},
+	})
+	obs, err := adapter.NewHookObservability(*config)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	angola := adapter.NewMarketContext(constants.MarketAngola, "Financial", "ScopeValidation")
+	fast := func(context.Context) error { return nil }
+	slow := func(context.Context) error {
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, obs.ObserveValidateScope(ctx, angola, "user-1", "payments:write", fast))
+	}
+	require.NoError(t, obs.ObserveValidateScope(ctx, angola, "user-1", "payments:write", slow))
+	// Operações sem orçamento não são avaliadas
+	require.NoError(t, obs.ObserveHookOperation(ctx, angola, constants.OperationAuthorizeAccess, "user-1", "Autorização", nil, slow))
+
+	exceeded := map[string]string{
+		"market":      constants.MarketAngola,
+		"tenant_type": "Financial",
+		"hook_type":   "ScopeValidation",
+		"operation":   constants.OperationValidateScope,
+	}
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricHookLatencyBudgetTotal, exceeded))
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricHookLatencyBudgetTotal, nil), "apenas a validação lenta")
+
+	events := map[string]string{
+		"market":     constants.MarketAngola,
+		"severity":   adapter.DefaultLatencyBudgetSeverity,
+		"event_type": adapter.LatencyBudgetExceededEvent,
+	}
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricSecurityEventsTotal, events))
+
+	// O span da operação lenta regista o orçamento excedido
+	obs.Close()
+	marked := 0
+	for _, span := range exporter.spans {
+		if span.Name() != "hook."+constants.OperationValidateScope {
+			continue
+		}
+		for _, event := range span.Events() {
+			if event.Name == adapter.LatencyBudgetExceededEvent {
+				marked++
+			}
+		}
+	}
+	assert.Equal(t, 1, marked)
+}
+
+// TestLatencyBudgetCancel verifica o cancelamento do contexto e a precedência do orçamento
+// do tipo de hook sobre o orçamento da operação
+func TestLatencyBudgetCancel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := &adapter.Config{
+		Environment:     "test",
+		ServiceName:     "test-service",
+		LogLevel:        "error",
+		MetricsRegistry: registry,
+		SpanExporter:    tracetest.NewInMemoryExporter(),
+	}
+	config.WithLatencyBudgets(adapter.LatencyBudgetConfig{
+		Budgets: []adapter.LatencyBudget{
+			adapter.HookLatencyBudget(constants.OperationValidateScope, time.Second),
+			adapter.HookLatencyBudget(constants.OperationValidateScope, 10*time.Millisecond).
+				WithHookType("ScopeValidation").
+				WithCancel(),
+		},
+		Severity: constants.SeverityHigh,
+	})
+	obs, err := adapter.NewHookObservability(*config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	ctx := context.Background()
+	wait := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+			return nil
+		}
+	}
+
+	// O orçamento do tipo de hook cancela a operação ao fim de 10ms
+	scope := adapter.NewMarketContext(constants.MarketBrazil, "Financial", "ScopeValidation")
+	start := time.Now()
+	err = obs.ObserveValidateScope(ctx, scope, "user-1", "payments:write", wait)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Os outros tipos de hook usam o orçamento da operação, que não cancela o contexto
+	other := adapter.NewMarketContext(constants.MarketBrazil, "Financial", "PrivilegeElevation")
+	require.NoError(t, obs.ObserveValidateScope(ctx, other, "user-1", "payments:write", func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.False(t, hasDeadline)
+		return nil
+	}))
+
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricHookLatencyBudgetTotal,
+		map[string]string{"hook_type": "ScopeValidation"}))
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricSecurityEventsTotal,
+		map[string]string{"severity": constants.SeverityHigh, "event_type": adapter.LatencyBudgetExceededEvent}))
+	assert.Equal(t, 1.0, gatheredCounter(t, registry, adapter.MetricHookErrorsTotal,
+		map[string]string{"hook_type": "ScopeValidation"}))
+}
+
+// TestLatencyBudgetConfigValidate verifica a validação dos orçamentos de latência
+func TestLatencyBudgetConfigValidate(t *testing.T) {
+	valid := adapter.HookLatencyBudget(constants.OperationValidateScope, 50*time.Millisecond)
+
+	require.NoError(t, adapter.LatencyBudgetConfig{Budgets: []adapter.LatencyBudget{valid}}.Validate())
+	require.NoError(t, adapter.LatencyBudgetConfig{
+		Budgets: []adapter.LatencyBudget{valid, valid.WithHookType("ScopeValidation")},
+	}.Validate(), "orçamento por tipo de hook ao lado do orçamento da operação")
+
+	assert.ErrorIs(t, adapter.LatencyBudgetConfig{}.Validate(), adapter.ErrLatencyBudgetNotConfigured)
+	assert.Error(t, adapter.LatencyBudgetConfig{Budgets: []adapter.LatencyBudget{valid, valid}}.Validate(), "orçamento duplicado")
+	assert.Error(t, adapter.LatencyBudgetConfig{
+		Budgets: []adapter.LatencyBudget{adapter.HookLatencyBudget("", time.Millisecond)},
+	}.Validate(), "orçamento sem operação")
+	assert.Error(t, adapter.LatencyBudgetConfig{
+		Budgets: []adapter.LatencyBudget{adapter.HookLatencyBudget(constants.OperationValidateMFA, 0)},
+	}.Validate(), "orçamento nulo")
+	assert.Error(t, adapter.LatencyBudgetConfig{
+		Budgets:  []adapter.LatencyBudget{valid},
+		Severity: "urgent",
+	}.Validate(), "severidade desconhecida")
+
+	config := &adapter.Config{Environment: "test", ServiceName: "test-service"}
+	config.WithLatencyBudgets(adapter.LatencyBudgetConfig{})
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,181 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam os namespaces das métricas por instância, o registo em registos
+// Prometheus e meters OpenTelemetry partilhados e a deteção de registos duplicados.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// namespacedConfig cria a configuração de um módulo sem porta de métricas, com o namespace indicado
+func namespacedConfig(serviceName, namespace string) *adapter.Config {
+	config := &adapter.Config{
+		Environment: "test",
+		ServiceName: serviceName,
+		LogLevel:    "error",
+	}
+	return config.WithMetricNamespace(namespace)
+}
+
+// gatheredNames retorna os nomes das métricas presentes no registo
+func gatheredNames(t *testing.T, registry *prometheus.Registry) map[string]bool {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	names := make(map[string]bool, len(families))
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	return names
+}
+
+// TestMetricNamespacesSharedRegistry verifica que dois módulos com namespaces distintos partilham o registo
+func TestMetricNamespacesSharedRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, "Financial", "PaymentGateway")
+
+	gateway, err := adapter.NewHookObservability(*namespacedConfig("payment-gateway", "payment_gateway").WithMetricsRegistry(registry))
+	require.NoError(t, err)
+	defer gateway.Close()
+
+	bureau, err := adapter.NewHookObservability(*namespacedConfig("bureau-client", "bureau_client").WithMetricsRegistry(registry))
+	require.NoError(t, err)
+	defer bureau.Close()
+
+	err = gateway.ObserveHookOperation(context.Background(), marketCtx, "authorize", "user-1", "Autorização",
+		[]attribute.KeyValue{}, func(ctx context.Context) error { return nil })
+	require.NoError(t, err)
+	bureau.RecordMetric(marketCtx, "payment_gateway_transaction_count", "approved", 1)
+
+	names := gatheredNames(t, registry)
+	assert.True(t, names["payment_gateway_hook_calls_total"])
+	assert.True(t, names["bureau_client_payment_gateway_transaction_count"])
+	assert.False(t, names[adapter.MetricHookCallsTotal], "o namespace substitui o prefixo padrão")
+	for name := range names {
+		assert.False(t, strings.HasPrefix(name, "go_") || strings.HasPrefix(name, "process_"),
+			"as métricas padrão ficam a cargo de quem criou o registo: %s", name)
+	}
+}
+
+// TestMetricNamespaceConflict verifica que o mesmo namespace no mesmo registo é recusado com erro claro
+func TestMetricNamespaceConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	gateway, err := adapter.NewHookObservability(*namespacedConfig("payment-gateway", "").WithMetricsRegistry(registry))
+	require.NoError(t, err)
+
+	_, err = adapter.NewHookObservability(*namespacedConfig("bureau-client", "").WithMetricsRegistry(registry))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, adapter.ErrMetricConflict)
+	assert.Contains(t, err.Error(), "payment-gateway")
+	assert.Contains(t, err.Error(), "bureau-client")
+
+	// Registos diferentes não entram em conflito
+	other, err := adapter.NewHookObservability(*namespacedConfig("bureau-client", "").WithMetricsRegistry(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	defer other.Close()
+
+	// Fechar a instância liberta os nomes no registo
+	gateway.Close()
+
+	bureau, err := adapter.NewHookObservability(*namespacedConfig("bureau-client", "").WithMetricsRegistry(registry))
+	require.NoError(t, err)
+	defer bureau.Close()
+	bureau.RecordTestCoverage("PaymentGateway", 85)
+	assert.True(t, gatheredNames(t, registry)[adapter.MetricTestCoveragePercent])
+}
+
+// TestMetricConflictOutsideAdapter verifica a conversão de registos feitos fora do adaptador
+func TestMetricConflictOutsideAdapter(t *testing.T) {
+	def, ok := adapter.LookupMetric(adapter.MetricHookCallsTotal)
+	require.True(t, ok)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "legacy_hook_calls_total",
+		Help: def.Help,
+	}, def.Labels))
+
+	_, err := adapter.NewHookObservability(*namespacedConfig("legacy", "legacy").WithMetricsRegistry(registry))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, adapter.ErrMetricConflict)
+
+	// Nada fica registado após o conflito
+	assert.NoError(t, registry.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "legacy_hook_errors_total",
+		Help: "Contador registado diretamente pelo módulo legado",
+	}, []string{"market"})))
+}
+
+// TestMetricNamespaceValidation verifica a validação do namespace na configuração
+func TestMetricNamespaceValidation(t *testing.T) {
+	assert.NoError(t, namespacedConfig("gateway", "payment_gateway").Validate())
+	assert.Error(t, namespacedConfig("gateway", "payment-gateway").Validate())
+	assert.Error(t, namespacedConfig("gateway", "9gateway").Validate())
+}
+
+// TestMetricsMeterProvider verifica o registo das métricas num meter OpenTelemetry
+func TestMetricsMeterProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+	marketCtx := adapter.NewMarketContext(constants.MarketBrazil, "Retail", "PaymentGateway")
+
+	obs, err := adapter.NewHookObservability(*namespacedConfig("payment-gateway", "payment_gateway").WithMeterProvider(provider))
+	require.NoError(t, err)
+
+	_, err = adapter.NewHookObservability(*namespacedConfig("bureau-client", "payment_gateway").WithMeterProvider(provider))
+	assert.ErrorIs(t, err, adapter.ErrMetricConflict, "o mesmo namespace no mesmo MeterProvider é recusado")
+
+	err = obs.ObserveHookOperation(context.Background(), marketCtx, "authorize", "user-1", "Autorização",
+		[]attribute.KeyValue{}, func(ctx context.Context) error { return nil })
+	require.NoError(t, err)
+	obs.UpdateActiveElevations(marketCtx, 3)
+
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &collected))
+	values := make(map[string]metricdata.Aggregation)
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			values[m.Name] = m.Data
+		}
+	}
+
+	calls, ok := values["payment_gateway_hook_calls_total"].(metricdata.Sum[float64])
+	require.True(t, ok)
+	require.Len(t, calls.DataPoints, 1)
+	assert.Equal(t, 1.0, calls.DataPoints[0].Value)
+	market, _ := calls.DataPoints[0].Attributes.Value("market")
+	assert.Equal(t, constants.MarketBrazil, market.AsString())
+
+	elevations, ok := values["payment_gateway_active_elevations"].(metricdata.Gauge[float64])
+	require.True(t, ok)
+	require.Len(t, elevations.DataPoints, 1)
+	assert.Equal(t, 3.0, elevations.DataPoints[0].Value)
+
+	// Fechar a instância remove o callback do gauge
+	obs.Close()
+	collected = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &collected))
+	for _, scope := range collected.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[float64]); ok {
+				assert.Empty(t, gauge.DataPoints)
+			}
+		}
+	}
+}
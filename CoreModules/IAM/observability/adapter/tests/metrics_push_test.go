@@ -0,0 +1,171 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam o envio das métricas dos jobs em lote para o Pushgateway,
+// incluindo o resumo de cada job e a remoção do grupo da execução após sucesso.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// pushRequest é um pedido recebido pelo Pushgateway de teste
+type pushRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakePushgateway regista os pedidos recebidos e responde como o Pushgateway ou com o estado configurado
+type fakePushgateway struct {
+	mu       sync.Mutex
+	requests []pushRequest
+	status   int
+}
+
+func (g *fakePushgateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, pushRequest{Method: r.Method, Path: r.URL.Path, Body: string(body)})
+	if g.status != 0 {
+		w.WriteHeader(g.status)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// newBatchObservability cria um adaptador sem porta de métricas, com envio para o Pushgateway de teste
+func newBatchObservability(t *testing.T, deleteOnSuccess bool) (*adapter.HookObservability, *fakePushgateway) {
+	t.Helper()
+
+	gateway := &fakePushgateway{}
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+
+	config := adapter.Config{
+		Environment: "test",
+		ServiceName: "reconciliation-job",
+		LogLevel:    "error",
+		MetricsPush: &adapter.PushConfig{
+			URL:             server.URL,
+			Instance:        "worker-1",
+			Grouping:        map[string]string{"region": "af-south"},
+			DeleteOnSuccess: deleteOnSuccess,
+		},
+	}
+
+	obs, err := adapter.NewHookObservability(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { obs.Close() })
+	return obs, gateway
+}
+
+// TestBatchJobPushOnFailure verifica que uma execução falhada envia o resumo e as métricas da execução
+func TestBatchJobPushOnFailure(t *testing.T) {
+	obs, gateway := newBatchObservability(t, true)
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, "Financial", "Reconciliation")
+
+	job := obs.StartBatchJob(marketCtx, "reconciliation")
+	// Sem porta de métricas, as operações observadas continuam a registar métricas
+	err := obs.ObserveHookOperation(context.Background(), marketCtx, "reconcile", "system", "Reconciliação diária",
+		[]attribute.KeyValue{}, func(ctx context.Context) error { return nil })
+	require.NoError(t, err)
+
+	require.NoError(t, job.Finish(context.Background(), errors.New("extrato indisponível")))
+
+	require.Len(t, gateway.requests, 2)
+	summary := gateway.requests[0]
+	assert.Equal(t, http.MethodPost, summary.Method, "o resumo é acrescentado para manter o último sucesso")
+	assert.Equal(t, "/metrics/job/reconciliation/region/af-south", summary.Path)
+	assert.Contains(t, summary.Body, adapter.MetricBatchJobSuccess)
+	assert.Contains(t, summary.Body, adapter.MetricBatchJobDuration)
+	assert.NotContains(t, summary.Body, adapter.MetricBatchJobLastSuccess)
+
+	run := gateway.requests[1]
+	assert.Equal(t, http.MethodPut, run.Method)
+	assert.Equal(t, "/metrics/job/reconciliation/instance/worker-1/region/af-south", run.Path)
+	assert.Contains(t, run.Body, adapter.MetricHookCallsTotal)
+
+	assert.Error(t, job.Finish(context.Background(), nil), "uma execução só termina uma vez")
+}
+
+// TestBatchJobSuccess verifica o envio após sucesso, com e sem remoção do grupo da execução
+func TestBatchJobSuccess(t *testing.T) {
+	marketCtx := adapter.NewMarketContext(constants.MarketBrazil, "Retail", "Reports")
+
+	t.Run("Remoção do grupo da execução", func(t *testing.T) {
+		obs, gateway := newBatchObservability(t, true)
+
+		require.NoError(t, obs.StartBatchJob(marketCtx, "report-generation").Finish(context.Background(), nil))
+
+		require.Len(t, gateway.requests, 2)
+		assert.Equal(t, http.MethodPost, gateway.requests[0].Method)
+		assert.Contains(t, gateway.requests[0].Body, adapter.MetricBatchJobLastSuccess)
+		assert.Equal(t, http.MethodDelete, gateway.requests[1].Method)
+		assert.Equal(t, "/metrics/job/report-generation/instance/worker-1/region/af-south", gateway.requests[1].Path)
+	})
+
+	t.Run("Envio do grupo da execução", func(t *testing.T) {
+		obs, gateway := newBatchObservability(t, false)
+
+		require.NoError(t, obs.StartBatchJob(marketCtx, "report-generation").Finish(context.Background(), nil))
+
+		require.Len(t, gateway.requests, 2)
+		assert.Equal(t, http.MethodPut, gateway.requests[1].Method)
+	})
+
+	t.Run("Falha do Pushgateway", func(t *testing.T) {
+		obs, gateway := newBatchObservability(t, false)
+		gateway.status = http.StatusServiceUnavailable
+
+		assert.Error(t, obs.StartBatchJob(marketCtx, "report-generation").Finish(context.Background(), nil))
+	})
+}
+
+// TestPushConfigValidate valida a configuração do envio de métricas
+func TestPushConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config adapter.PushConfig
+		valid  bool
+	}{
+		{"Válida", adapter.PushConfig{URL: "http://pushgateway:9091", Grouping: map[string]string{"region": "eu"}}, true},
+		{"Sem URL", adapter.PushConfig{}, false},
+		{"URL sem esquema", adapter.PushConfig{URL: "pushgateway:9091"}, false},
+		{"Rótulo reservado", adapter.PushConfig{URL: "http://pushgateway:9091", Grouping: map[string]string{"instance": "x"}}, false},
+		{"Timeout negativo", adapter.PushConfig{URL: "http://pushgateway:9091", Timeout: -1}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	config := adapter.Config{Environment: "test", ServiceName: "job", MetricsPush: &adapter.PushConfig{}}
+	assert.Error(t, config.Validate(), "a configuração do adaptador valida o envio de métricas")
+}
@@ -0,0 +1,224 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam a alteração em tempo de execução do nível de log, da amostragem e das
+// métricas, a reposição automática das alterações temporárias e a autenticação do endpoint
+// administrativo.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Token administrativo usado nos testes
+const testAdminToken = "runtime-admin-token-0123456789abcdef"
+
+// runtimeConfig cria a configuração de um adaptador com endpoint administrativo e registo próprio
+func runtimeConfig(environment string) adapter.Config {
+	config := &adapter.Config{
+		Environment:     environment,
+		ServiceName:     "test-service",
+		LogLevel:        "info",
+		MetricsRegistry: prometheus.NewRegistry(),
+	}
+	return *config.WithRuntimeAdmin(adapter.RuntimeAdminConfig{
+		Tokens: map[string]string{testAdminToken: "ops@innovabiz"},
+	})
+}
+
+// adminRequest executa um pedido no endpoint administrativo com o token indicado
+func adminRequest(t *testing.T, obs *adapter.HookObservability, method, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, adapter.DefaultRuntimeAdminPath, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	obs.RuntimeAdminHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRuntimeConfigUpdate verifica a alteração do nível de log, da amostragem e das métricas
+func TestRuntimeConfigUpdate(t *testing.T) {
+	config := runtimeConfig("test")
+	obs, err := adapter.NewHookObservability(config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	current := obs.RuntimeConfig()
+	assert.Equal(t, "info", current.LogLevel)
+	assert.Equal(t, 1.0, current.TraceSampleRate)
+	assert.Empty(t, current.DisabledMetrics)
+
+	level := "debug"
+	rate := 0.25
+	current, err = obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", adapter.RuntimeConfigChange{
+		LogLevel:        &level,
+		TraceSampleRate: &rate,
+		Metrics:         map[string]bool{"payment_gateway_transaction_count": false},
+		Reason:          "investigação de incidente",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", current.LogLevel)
+	assert.Equal(t, 0.25, current.TraceSampleRate)
+	assert.Equal(t, []string{"payment_gateway_transaction_count"}, current.DisabledMetrics)
+	assert.Nil(t, current.RevertAt, "alteração permanente não tem reposição")
+
+	// A métrica desativada ignora os valores
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, "Financial", "PaymentGateway")
+	obs.RecordMetric(marketCtx, "payment_gateway_transaction_count", "approved", 1)
+	names := gatheredNames(t, config.MetricsRegistry)
+	assert.False(t, names[adapter.DefaultMetricNamespace+"_payment_gateway_transaction_count"])
+
+	_, err = obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", adapter.RuntimeConfigChange{
+		Metrics: map[string]bool{"payment_gateway_transaction_count": true},
+	})
+	require.NoError(t, err)
+	obs.RecordMetric(marketCtx, "payment_gateway_transaction_count", "approved", 1)
+	names = gatheredNames(t, config.MetricsRegistry)
+	assert.True(t, names[adapter.DefaultMetricNamespace+"_payment_gateway_transaction_count"])
+
+	history := obs.RuntimeConfigHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, "ops@innovabiz", history[0].Operator)
+	assert.Equal(t, "investigação de incidente", history[0].Reason)
+	assert.Equal(t, "info", history[0].Previous.LogLevel)
+	assert.Equal(t, "debug", history[0].Current.LogLevel)
+}
+
+// TestRuntimeConfigInvalidChanges verifica a recusa de alterações inválidas
+func TestRuntimeConfigInvalidChanges(t *testing.T) {
+	obs, err := adapter.NewHookObservability(runtimeConfig("production"))
+	require.NoError(t, err)
+	defer obs.Close()
+
+	level := "trace"
+	rate := 1.5
+	debug := "debug"
+	cases := map[string]adapter.RuntimeConfigChange{
+		"sem alterações":               {},
+		"nível desconhecido":           {LogLevel: &level},
+		"amostragem fora do intervalo": {TraceSampleRate: &rate},
+		"métrica desconhecida":         {Metrics: map[string]bool{"unknown_total": false}},
+		"duração acima do máximo":      {LogLevel: &debug, RevertAfter: 48 * time.Hour},
+		"debug permanente em produção": {LogLevel: &debug},
+	}
+	for name, change := range cases {
+		_, err := obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", change)
+		assert.ErrorIs(t, err, adapter.ErrInvalidRuntimeChange, name)
+	}
+	assert.Empty(t, obs.RuntimeConfigHistory())
+
+	// Em produção, o nível debug é aceite como alteração temporária
+	current, err := obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", adapter.RuntimeConfigChange{
+		LogLevel:    &debug,
+		RevertAfter: time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", current.LogLevel)
+	require.NotNil(t, current.RevertAt)
+}
+
+// TestRuntimeConfigAutoRevert verifica a reposição automática da configuração anterior
+func TestRuntimeConfigAutoRevert(t *testing.T) {
+	obs, err := adapter.NewHookObservability(runtimeConfig("test"))
+	require.NoError(t, err)
+	defer obs.Close()
+
+	debug := "debug"
+	rate := 0.5
+	_, err = obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", adapter.RuntimeConfigChange{
+		LogLevel:    &debug,
+		RevertAfter: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Uma alteração permanente durante a alteração temporária mantém-se após a reposição
+	_, err = obs.UpdateRuntimeConfig(context.Background(), "ops@innovabiz", adapter.RuntimeConfigChange{
+		TraceSampleRate: &rate,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return obs.RuntimeConfig().LogLevel == "info"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	current := obs.RuntimeConfig()
+	assert.Equal(t, 0.5, current.TraceSampleRate)
+	assert.Nil(t, current.RevertAt)
+
+	history := obs.RuntimeConfigHistory()
+	require.Len(t, history, 3)
+	assert.True(t, history[2].Revert)
+	assert.Equal(t, adapter.RuntimeRevertOperator, history[2].Operator)
+
+	_, err = obs.RevertRuntimeConfig(context.Background(), "ops@innovabiz", "")
+	assert.ErrorIs(t, err, adapter.ErrNoTemporaryChange)
+}
+
+// TestRuntimeAdminHandler verifica a autenticação e as operações do endpoint administrativo
+func TestRuntimeAdminHandler(t *testing.T) {
+	obs, err := adapter.NewHookObservability(runtimeConfig("test"))
+	require.NoError(t, err)
+	defer obs.Close()
+
+	rec := adminRequest(t, obs, http.MethodGet, "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = adminRequest(t, obs, http.MethodPatch, "wrong-token-0123456789abcdef0123456789", `{"log_level":"debug"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "info", obs.RuntimeConfig().LogLevel)
+
+	rec = adminRequest(t, obs, http.MethodPatch, testAdminToken,
+		`{"log_level":"debug","trace_sample_rate":0.1,"revert_after":"10m","reason":"diagnóstico"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Config  adapter.RuntimeConfig        `json:"config"`
+		History []adapter.RuntimeConfigAudit `json:"history"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "debug", resp.Config.LogLevel)
+	assert.Equal(t, 0.1, resp.Config.TraceSampleRate)
+	require.NotNil(t, resp.Config.RevertAt)
+
+	rec = adminRequest(t, obs, http.MethodPatch, testAdminToken, `{"revert_after":"soon","log_level":"warn"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = adminRequest(t, obs, http.MethodDelete, testAdminToken, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "info", obs.RuntimeConfig().LogLevel)
+	assert.Equal(t, 1.0, obs.RuntimeConfig().TraceSampleRate)
+
+	rec = adminRequest(t, obs, http.MethodDelete, testAdminToken, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = adminRequest(t, obs, http.MethodGet, testAdminToken, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.History, 2)
+	assert.Equal(t, "ops@innovabiz", resp.History[0].Operator)
+	assert.Equal(t, "10m0s", resp.History[0].RevertAfter)
+	assert.True(t, resp.History[1].Revert)
+}
+
+// TestRuntimeAdminConfigValidation verifica a recusa de tokens fracos
+func TestRuntimeAdminConfigValidation(t *testing.T) {
+	config := &adapter.Config{Environment: "test", ServiceName: "test-service"}
+	config.WithRuntimeAdmin(adapter.RuntimeAdminConfig{Tokens: map[string]string{"short": "ops"}})
+	_, err := adapter.NewHookObservability(*config)
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam o cálculo multi-janela do burn rate dos SLOs a partir dos histogramas do
+// adaptador, as métricas e o endpoint de estado, os exemplars com o trace dos eventos fora do
+// objetivo e a validação da configuração.
+//
+// Conformidades: ISO/IEC 27001, ISO 20000, COBIT 2019, TOGAF 10.0, DMBOK 2.0
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// sloClock é um relógio controlado pelos testes
+type sloClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *sloClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *sloClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// gatheredGauge retorna o valor do gauge com os rótulos indicados
+func gatheredGauge(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.Metric {
+			if metricHasLabels(m, labels) {
+				return m.GetGauge().GetValue(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// metricHasLabels indica se a série tem todos os rótulos indicados
+func metricHasLabels(m *dto.Metric, labels map[string]string) bool {
+	values := make(map[string]string, len(m.Label))
+	for _, pair := range m.Label {
+		values[pair.GetName()] = pair.GetValue()
+	}
+	for name, value := range labels {
+		if values[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSLOBurnRate verifica o burn rate multi-janela, as métricas, o endpoint e os exemplars
+func TestSLOBurnRate(t *testing.T) {
+	clock := &sloClock{now: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)}
+	registry := prometheus.NewRegistry()
+
+	config := &adapter.Config{
+		Environment:     "test",
+		ServiceName:     "test-service",
+		LogLevel:        "error",
+		MetricsRegistry: registry,
+		SpanExporter:    tracetest.NewInMemoryExporter(),
+	}
+	config.WithSLOs(adapter.SLOConfig{
+		Objectives: []adapter.SLODefinition{
+			adapter.HookLatencySLO("scope-validation-latency", constants.OperationValidateScope, 10*time.Millisecond, 0.999),
+		},
+		EvaluationInterval: time.Hour,
+		Now:                clock.Now,
+	})
+	obs, err := adapter.NewHookObservability(*config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	ctx := context.Background()
+	angola := adapter.NewMarketContext(constants.MarketAngola, "Financial", "ScopeValidation")
+	brazil := adapter.NewMarketContext(constants.MarketBrazil, "Financial", "ScopeValidation")
+	fast := func(context.Context) error { return nil }
+	slow := func(context.Context) error {
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	}
+
+	// Angola: 2 validações lentas em 100 consomem o orçamento 20 vezes mais depressa que o previsto
+	for i := 0; i < 98; i++ {
+		require.NoError(t, obs.ObserveValidateScope(ctx, angola, "user-1", "payments:write", fast))
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, obs.ObserveValidateScope(ctx, angola, "user-1", "payments:write", slow))
+	}
+	// Brazil: apenas validações rápidas
+	for i := 0; i < 50; i++ {
+		require.NoError(t, obs.ObserveValidateScope(ctx, brazil, "user-2", "payments:read", fast))
+	}
+	// Outras operações não entram no SLO
+	require.NoError(t, obs.ObserveHookOperation(ctx, angola, "authorize", "user-1", "Autorização", nil, slow))
+
+	clock.Advance(time.Minute)
+	obs.EvaluateSLOs()
+
+	statuses := obs.SLOStatus(constants.MarketAngola)
+	require.Len(t, statuses, 1)
+	status := statuses[0]
+	assert.Equal(t, "scope-validation-latency", status.SLO)
+	assert.Equal(t, 100.0, status.TotalEvents)
+	assert.Equal(t, 2.0, status.BadEvents)
+	assert.InDelta(t, 0.98, status.Attainment, 1e-9)
+	assert.InDelta(t, -19, status.ErrorBudgetRemaining, 1e-6)
+	require.NotEmpty(t, status.BurnRates)
+	assert.Equal(t, "5m", status.BurnRates[0].Window)
+	assert.InDelta(t, 20, status.BurnRates[0].BurnRate, 1e-6)
+	assert.Equal(t, "1m", status.BurnRates[0].Covered, "janela coberta apenas pelo histórico disponível")
+	assert.ElementsMatch(t, []string{"critical", "warning"}, status.FiringSeverities())
+
+	// Os exemplars identificam os traces das validações lentas
+	require.NotEmpty(t, status.Exemplars)
+	for _, exemplar := range status.Exemplars {
+		assert.Len(t, exemplar.TraceID, 32)
+		assert.Greater(t, exemplar.Value, 0.01)
+	}
+
+	firing, ok := gatheredGauge(t, registry, adapter.MetricSLOAlertFiring,
+		map[string]string{"market": constants.MarketAngola, "slo": "scope-validation-latency", "severity": "critical"})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, firing)
+	rate, ok := gatheredGauge(t, registry, adapter.MetricSLOBurnRate,
+		map[string]string{"market": constants.MarketAngola, "slo": "scope-validation-latency", "window": "1h"})
+	require.True(t, ok)
+	assert.InDelta(t, 20, rate, 1e-6)
+
+	brazilStatus := obs.SLOStatus(constants.MarketBrazil)
+	require.Len(t, brazilStatus, 1)
+	assert.Equal(t, 1.0, brazilStatus[0].ErrorBudgetRemaining)
+	assert.Empty(t, brazilStatus[0].FiringSeverities())
+
+	// Duas horas depois, as janelas curtas até 2h só contêm validações rápidas e os alertas
+	// critical resolvem-se; o alerta 3d/6h continua ativo porque as duas janelas incluem as lentas
+	clock.Advance(2 * time.Hour)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, obs.ObserveValidateScope(ctx, angola, "user-1", "payments:write", fast))
+	}
+	clock.Advance(time.Minute)
+	obs.EvaluateSLOs()
+
+	status = obs.SLOStatus(constants.MarketAngola)[0]
+	assert.Equal(t, []string{"warning"}, status.FiringSeverities())
+	rates := make(map[string]float64)
+	for _, burn := range status.BurnRates {
+		rates[burn.Window] = burn.BurnRate
+	}
+	assert.Equal(t, 0.0, rates["5m"])
+	assert.InDelta(t, 10, rates["6h"], 1e-6)
+	firing, _ = gatheredGauge(t, registry, adapter.MetricSLOAlertFiring,
+		map[string]string{"market": constants.MarketAngola, "slo": "scope-validation-latency", "severity": "critical"})
+	assert.Equal(t, 0.0, firing)
+
+	// Endpoint de estado por mercado
+	req := httptest.NewRequest(http.MethodGet, adapter.DefaultSLOPath+"?market="+constants.MarketAngola, nil)
+	rec := httptest.NewRecorder()
+	obs.SLOHandler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Service string              `json:"service"`
+		SLOs    []adapter.SLOStatus `json:"slos"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "test-service", body.Service)
+	require.Len(t, body.SLOs, 1)
+	assert.Equal(t, constants.MarketAngola, body.SLOs[0].Market)
+	assert.Len(t, obs.SLOStatus(""), 2)
+}
+
+// TestSLOExemplarsOnHistogram verifica que a duração dos hooks amostrados leva o trace como exemplar
+func TestSLOExemplarsOnHistogram(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	config := &adapter.Config{
+		Environment:     "test",
+		ServiceName:     "test-service",
+		LogLevel:        "error",
+		MetricsRegistry: registry,
+		SpanExporter:    tracetest.NewInMemoryExporter(),
+	}
+	obs, err := adapter.NewHookObservability(*config)
+	require.NoError(t, err)
+	defer obs.Close()
+
+	marketCtx := adapter.NewMarketContext(constants.MarketEU, "Financial", "ScopeValidation")
+	require.NoError(t, obs.ObserveValidateScope(context.Background(), marketCtx, "user-1", "profile:read",
+		func(context.Context) error { return nil }))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	exemplars := 0
+	for _, family := range families {
+		if family.GetName() != adapter.MetricHookDurationSeconds {
+			continue
+		}
+		for _, m := range family.Metric {
+			for _, bucket := range m.GetHistogram().Bucket {
+				if exemplar := bucket.Exemplar; exemplar != nil {
+					exemplars++
+					require.Len(t, exemplar.Label, 1)
+					assert.Equal(t, adapter.ExemplarTraceIDLabel, exemplar.Label[0].GetName())
+					assert.Len(t, exemplar.Label[0].GetValue(), 32)
+				}
+			}
+		}
+	}
+	assert.Equal(t, 1, exemplars)
+}
+
+// TestSLOConfigValidate verifica a validação dos SLOs contra o catálogo de métricas
+func TestSLOConfigValidate(t *testing.T) {
+	valid := adapter.HookLatencySLO("scope-validation-latency", constants.OperationValidateScope, 100*time.Millisecond, 0.999)
+
+	tests := []struct {
+		name   string
+		modify func(*adapter.SLODefinition)
+	}{
+		{"Métrica inexistente", func(d *adapter.SLODefinition) { d.Metric = "unknown_seconds" }},
+		{"Métrica que não é histograma", func(d *adapter.SLODefinition) { d.Metric = "hook_calls_total" }},
+		{"Limite fora dos buckets", func(d *adapter.SLODefinition) { d.Threshold = 0.2 }},
+		{"Objetivo inválido", func(d *adapter.SLODefinition) { d.Objective = 1 }},
+		{"Filtro por rótulo inexistente", func(d *adapter.SLODefinition) { d.Matchers = map[string]string{"scope": "x"} }},
+		{"Filtro por mercado", func(d *adapter.SLODefinition) { d.Matchers = map[string]string{"market": "Angola"} }},
+	}
+
+	require.NoError(t, adapter.SLOConfig{Objectives: []adapter.SLODefinition{valid}}.Validate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slo := valid
+			tt.modify(&slo)
+			assert.Error(t, adapter.SLOConfig{Objectives: []adapter.SLODefinition{slo}}.Validate())
+		})
+	}
+
+	assert.ErrorIs(t, adapter.SLOConfig{}.Validate(), adapter.ErrSLONotConfigured)
+	assert.Error(t, adapter.SLOConfig{Objectives: []adapter.SLODefinition{valid, valid}}.Validate(), "SLO duplicado")
+	assert.Error(t, adapter.SLOConfig{
+		Objectives: []adapter.SLODefinition{valid},
+		Alerts:     []adapter.BurnRateAlert{{Severity: "critical", LongWindow: time.Minute, ShortWindow: time.Hour, BurnRate: 14.4}},
+	}.Validate(), "janela curta maior que a longa")
+
+	config := &adapter.Config{Environment: "test", ServiceName: "test-service"}
+	config.WithSLOs(adapter.SLOConfig{Objectives: []adapter.SLODefinition{valid}})
+	assert.Error(t, config.Validate(), "SLOs sem métricas ativas")
+}
@@ -0,0 +1,245 @@
+// Package tests fornece testes unitários para o adaptador de observabilidade MCP-IAM
+//
+// Estes testes validam a redação de spans antes da exportação, garantindo que
+// identificadores, documentos e escopos não saem do processo em texto claro.
+//
+// Conformidades: GDPR, LGPD, Lei 22/11 (Angola), ISO/IEC 27701
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/innovabiz/iam/constants"
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Chave de pseudonimização usada nos testes
+var testPseudonymizationKey = []byte("0123456789abcdef0123456789abcdef")
+
+// captureExporter guarda os spans recebidos, incluindo após o encerramento do provedor
+type captureExporter struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *captureExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *captureExporter) Shutdown(context.Context) error { return nil }
+
+// exportedSpans cria um adaptador com exportador em memória, executa fn e devolve os spans exportados
+func exportedSpans(t *testing.T, redaction *adapter.SpanRedactionConfig, fn func(obs *adapter.HookObservability)) []sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	// As operações observadas exigem métricas ativas; usar uma porta livre
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	exporter := &captureExporter{}
+	config := adapter.Config{
+		Environment:   "test",
+		ServiceName:   "test-service",
+		LogLevel:      "error",
+		MetricsPort:   port,
+		RedactPII:     true,
+		SpanRedaction: redaction,
+		SpanExporter:  exporter,
+	}
+
+	obs, err := adapter.NewHookObservability(config)
+	require.NoError(t, err)
+
+	fn(obs)
+	// O encerramento do provedor de traces exporta os spans pendentes
+	obs.Close()
+
+	require.NotEmpty(t, exporter.spans)
+	return exporter.spans
+}
+
+// spanAttributes indexa os atributos do span por chave
+func spanAttributes(attrs []attribute.KeyValue) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		values[string(attr.Key)] = attr.Value.Emit()
+	}
+	return values
+}
+
+// assertNoLeak garante que nenhum dos valores aparece no span exportado
+func assertNoLeak(t *testing.T, span sdktrace.ReadOnlySpan, secrets ...string) {
+	t.Helper()
+
+	var exported []string
+	for _, attr := range span.Attributes() {
+		exported = append(exported, attr.Value.Emit())
+	}
+	for _, evt := range span.Events() {
+		for _, attr := range evt.Attributes {
+			exported = append(exported, attr.Value.Emit())
+		}
+	}
+	exported = append(exported, span.Status().Description)
+
+	for _, value := range exported {
+		for _, secret := range secrets {
+			assert.NotContains(t, value, secret, "span %s exportou PII", span.Name())
+		}
+	}
+}
+
+// euRedactionConfig retorna a redação padrão com chave de pseudonimização fixa
+func euRedactionConfig() *adapter.SpanRedactionConfig {
+	redaction := adapter.DefaultSpanRedactionConfig()
+	redaction.PseudonymizationKey = testPseudonymizationKey
+	return &redaction
+}
+
+// TestSpanRedactionEUPseudonymization valida a pseudonimização GDPR dos spans da UE
+func TestSpanRedactionEUPseudonymization(t *testing.T) {
+	userID := "6f1c2a7e-0b4d-4c8e-9a51-3d2f7e8b9c10"
+	scope := "payments:admin"
+	marketCtx := adapter.NewMarketContext(constants.MarketEU, constants.TenantFinancial, constants.HookTypePrivilegeElevation)
+
+	spans := exportedSpans(t, euRedactionConfig(), func(obs *adapter.HookObservability) {
+		for i := 0; i < 2; i++ {
+			err := obs.ObserveValidateScope(context.Background(), marketCtx, userID, scope, func(context.Context) error {
+				return nil
+			})
+			require.NoError(t, err)
+		}
+	})
+	require.Len(t, spans, 2)
+
+	redactor, err := adapter.NewSpanRedactor(*euRedactionConfig(), nil)
+	require.NoError(t, err)
+
+	first := spanAttributes(spans[0].Attributes())
+	second := spanAttributes(spans[1].Attributes())
+
+	assert.Equal(t, redactor.Pseudonymize(userID), first["user_id"])
+	assert.Equal(t, first["user_id"], second["user_id"], "pseudónimo deve ser estável entre spans")
+	assert.True(t, strings.HasPrefix(first["scope"], "pseud:"))
+	assert.NotContains(t, first, "description", "descrição repete o escopo e deve ser removida na UE")
+	assert.Equal(t, constants.MarketEU, first["market"])
+
+	for _, span := range spans {
+		assertNoLeak(t, span, userID, scope)
+	}
+}
+
+// TestSpanRedactionAllowList valida a remoção de atributos fora da lista permitida
+func TestSpanRedactionAllowList(t *testing.T) {
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, constants.TenantFinancial, constants.HookTypePrivilegeElevation)
+
+	t.Run("Lista padrão", func(t *testing.T) {
+		spans := exportedSpans(t, euRedactionConfig(), func(obs *adapter.HookObservability) {
+			attrs := []attribute.KeyValue{
+				attribute.String("ip_address", "10.20.30.40"),
+				attribute.String("documento_cliente", "004567890LA042"),
+				attribute.Int("elevation.duration", 30),
+			}
+			err := obs.ObserveHookOperation(context.Background(), marketCtx, "elevate", "user-1", "Elevação", attrs, func(context.Context) error {
+				return nil
+			})
+			require.NoError(t, err)
+		})
+
+		values := spanAttributes(spans[0].Attributes())
+		assert.NotContains(t, values, "ip_address")
+		assert.NotContains(t, values, "documento_cliente")
+		assert.NotContains(t, values, "elevation.duration")
+		assertNoLeak(t, spans[0], "10.20.30.40", "004567890LA042")
+	})
+
+	t.Run("Lista configurada com prefixo", func(t *testing.T) {
+		redaction := euRedactionConfig()
+		redaction.AllowedAttributes = append(redaction.AllowedAttributes, "elevation.*", "documento_cliente")
+
+		spans := exportedSpans(t, redaction, func(obs *adapter.HookObservability) {
+			attrs := []attribute.KeyValue{
+				attribute.String("ip_address", "10.20.30.40"),
+				attribute.String("documento_cliente", "004567890LA042"),
+				attribute.Int("elevation.duration", 30),
+			}
+			err := obs.ObserveHookOperation(context.Background(), marketCtx, "elevate", "user-1", "Elevação", attrs, func(context.Context) error {
+				return nil
+			})
+			require.NoError(t, err)
+		})
+
+		values := spanAttributes(spans[0].Attributes())
+		assert.NotContains(t, values, "ip_address")
+		assert.Equal(t, "30", values["elevation.duration"])
+		assert.Equal(t, "***42", values["documento_cliente"], "chaves sensíveis permitidas continuam mascaradas")
+		assertNoLeak(t, spans[0], "004567890LA042")
+	})
+}
+
+// TestSpanRedactionMarketRules valida a truncagem fora da UE e a redação de eventos e erros
+func TestSpanRedactionMarketRules(t *testing.T) {
+	userID := "ao-user-000123456"
+	document := "004567890LA042"
+	email := "cliente@example.ao"
+	marketCtx := adapter.NewMarketContext(constants.MarketAngola, constants.TenantFinancial, constants.HookTypePrivilegeElevation)
+
+	spans := exportedSpans(t, euRedactionConfig(), func(obs *adapter.HookObservability) {
+		obs.TraceSecurity(context.Background(), marketCtx, userID, "high",
+			"Tentativa de elevação para o BI "+document, "elevation_denied")
+
+		err := obs.ObserveHookOperation(context.Background(), marketCtx, "notify", userID, "Notificação", nil, func(context.Context) error {
+			return errors.New("falha ao notificar " + email)
+		})
+		require.Error(t, err)
+	})
+	require.Len(t, spans, 2)
+
+	for _, span := range spans {
+		values := spanAttributes(span.Attributes())
+		assert.Equal(t, "ao-user-…", values["user_id"])
+		assertNoLeak(t, span, userID, document, email)
+	}
+
+	var exception map[string]string
+	for _, evt := range spans[1].Events() {
+		if evt.Name == "exception" {
+			exception = spanAttributes(evt.Attributes)
+		}
+	}
+	require.NotNil(t, exception)
+	assert.Contains(t, exception["exception.message"], "falha ao notificar ***")
+}
+
+// TestSpanRedactionConfigValidation valida as regras de redação configuradas
+func TestSpanRedactionConfigValidation(t *testing.T) {
+	redaction := adapter.DefaultSpanRedactionConfig()
+	redaction.PseudonymizationKey = []byte("curta")
+	_, err := adapter.NewSpanRedactor(redaction, nil)
+	assert.Error(t, err)
+
+	redaction = adapter.DefaultSpanRedactionConfig()
+	redaction.MarketRules[constants.MarketUSA] = adapter.MarketRedactionRule{
+		Attributes: map[string]adapter.AttributeAction{"user_id": "encrypt"},
+	}
+	config := adapter.Config{
+		Environment:   "test",
+		ServiceName:   "test-service",
+		SpanRedaction: &redaction,
+	}
+	assert.Error(t, config.Validate())
+}
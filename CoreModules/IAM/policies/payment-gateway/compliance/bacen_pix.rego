@@ -0,0 +1,20 @@
+# Regra BACEN para pagamentos PIX - INNOVABIZ Platform
+#
+# Pagamentos PIX devem identificar a chave e o respetivo tipo.
+# Conformidade: BACEN Resolução BCB nº 1/2020
+package innovabiz.payment_gateway.compliance.bacen_pix
+
+import data.innovabiz.payment_gateway.compliance.common
+
+default decision = {"compliant": true, "message": "Compliance PIX verificado"}
+
+decision = {"compliant": false, "message": "Tipo de chave PIX não especificado"} {
+    common.transaction.payment_type == "pix"
+    not common.has_key(common.transaction.payment_details, "pix_key_type")
+}
+
+decision = {"compliant": false, "message": "Chave PIX não especificada"} {
+    common.transaction.payment_type == "pix"
+    common.has_key(common.transaction.payment_details, "pix_key_type")
+    not common.has_key(common.transaction.payment_details, "pix_key")
+}
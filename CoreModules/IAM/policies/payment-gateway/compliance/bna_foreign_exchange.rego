@@ -0,0 +1,14 @@
+# Regra BNA de controlo de câmbio - INNOVABIZ Platform
+#
+# Transações em moeda estrangeira no mercado angolano exigem autorização de câmbio.
+# Conformidade: BNA Aviso 3/2019
+package innovabiz.payment_gateway.compliance.bna_foreign_exchange
+
+import data.innovabiz.payment_gateway.compliance.common
+
+default decision = {"compliant": true, "message": "Compliance de câmbio BNA verificado"}
+
+decision = {"compliant": false, "message": "Falta autorização de câmbio BNA"} {
+    common.transaction.currency != "AOA"
+    not common.has_key(common.transaction.metadata, "exchange_authorization")
+}
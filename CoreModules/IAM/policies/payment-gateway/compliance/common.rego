@@ -0,0 +1,12 @@
+# Utilitários partilhados pelos pacotes de compliance do Payment Gateway - INNOVABIZ Platform
+#
+# Conformidade: PCI DSS v4.0, PSD2, BACEN, BNA
+package innovabiz.payment_gateway.compliance.common
+
+# Verifica a presença de uma chave, mesmo quando o valor é false ou null
+has_key(obj, key) {
+    _ = obj[key]
+}
+
+# Transação em avaliação, enviada pelo gateway em input.transaction
+transaction := input.transaction
@@ -0,0 +1,35 @@
+# Regra PCI DSS de proteção de dados de cartão - INNOVABIZ Platform
+#
+# Pagamentos com cartão não podem transportar o PAN completo e devem usar token ou PAN truncado.
+# Conformidade: PCI DSS v4.0 (Requisito 3)
+package innovabiz.payment_gateway.compliance.pci_dss
+
+import data.innovabiz.payment_gateway.compliance.common
+
+default decision = {"compliant": true, "message": "Compliance PCI DSS verificado"}
+
+card := common.transaction.payment_details.card
+
+card_payment {
+    common.transaction.payment_type == "card"
+    common.has_key(common.transaction.payment_details, "card")
+}
+
+decision = {"compliant": false, "message": "Formato inválido de dados do cartão"} {
+    card_payment
+    not is_object(card)
+}
+
+decision = {"compliant": false, "message": "Número completo do cartão não deveria estar presente"} {
+    card_payment
+    is_object(card)
+    common.has_key(card, "full_number")
+}
+
+decision = {"compliant": false, "message": "Token ou PAN truncado requerido por PCI DSS"} {
+    card_payment
+    is_object(card)
+    not common.has_key(card, "full_number")
+    not common.has_key(card, "token")
+    not common.has_key(card, "truncated_pan")
+}
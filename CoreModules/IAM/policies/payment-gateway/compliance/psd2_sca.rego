@@ -0,0 +1,46 @@
+# Regra PSD2 de autenticação forte (SCA) - INNOVABIZ Platform
+#
+# Transações em EUR acima do limiar exigem MFA de nível alto e, para cartões, dados 3D Secure.
+# O limiar pode ser ajustado pelo documento data.innovabiz.payment_gateway.config.psd2_sca.
+# Quando o gateway avalia as isenções (baixo valor, beneficiário de confiança e TRA), envia a decisão
+# em input.transaction.sca_exemption e a SCA é exigida sempre que nenhuma isenção foi aplicada.
+# Conformidade: PSD2 RTS (Regulamento Delegado (UE) 2018/389)
+package innovabiz.payment_gateway.compliance.psd2_sca
+
+import data.innovabiz.payment_gateway.compliance.common
+
+default decision = {"compliant": true, "message": "Compliance SCA verificado"}
+
+default amount_threshold = 30
+
+amount_threshold = data.innovabiz.payment_gateway.config.psd2_sca.amount_threshold
+
+requires_sca {
+    not common.has_key(common.transaction, "sca_exemption")
+    common.transaction.currency == "EUR"
+    common.transaction.amount > amount_threshold
+}
+
+requires_sca {
+    common.transaction.sca_exemption.outcome != "exempted"
+}
+
+sca_required_message = sprintf("SCA requerido para transação acima de %v EUR", [amount_threshold]) {
+    not common.has_key(common.transaction, "sca_exemption")
+}
+
+sca_required_message = sprintf("SCA requerido: %v", [common.transaction.sca_exemption.reason]) {
+    common.has_key(common.transaction, "sca_exemption")
+}
+
+decision = {"compliant": false, "message": sca_required_message} {
+    requires_sca
+    common.transaction.mfa_level != "high"
+}
+
+decision = {"compliant": false, "message": "Dados 3D Secure requeridos por PSD2"} {
+    requires_sca
+    common.transaction.mfa_level == "high"
+    common.transaction.payment_type == "card"
+    not common.has_key(common.transaction.three_ds_data, "authentication_status")
+}
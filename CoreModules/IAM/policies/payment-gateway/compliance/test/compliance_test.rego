@@ -0,0 +1,106 @@
+# Testes unitários dos pacotes de compliance do Payment Gateway - INNOVABIZ Platform
+#
+# Conformidade: PCI DSS v4.0, PSD2, BACEN, BNA
+package innovabiz.payment_gateway.compliance.compliance_test
+
+import data.innovabiz.payment_gateway.compliance.bacen_pix
+import data.innovabiz.payment_gateway.compliance.bna_foreign_exchange
+import data.innovabiz.payment_gateway.compliance.pci_dss
+import data.innovabiz.payment_gateway.compliance.psd2_sca
+
+# ---------------------------------------------------------
+# BNA - controlo de câmbio
+# ---------------------------------------------------------
+test_bna_local_currency_is_compliant {
+    bna_foreign_exchange.decision.compliant with input as {"transaction": {"currency": "AOA", "metadata": {}}}
+}
+
+test_bna_foreign_currency_requires_authorization {
+    not bna_foreign_exchange.decision.compliant with input as {"transaction": {"currency": "USD", "metadata": {}}}
+}
+
+test_bna_foreign_currency_with_authorization {
+    bna_foreign_exchange.decision.compliant with input as {
+        "transaction": {"currency": "USD", "metadata": {"exchange_authorization": "BNA-2025-001"}}
+    }
+}
+
+# ---------------------------------------------------------
+# BACEN - PIX
+# ---------------------------------------------------------
+test_pix_requires_key_type {
+    decision := bacen_pix.decision with input as {"transaction": {"payment_type": "pix", "payment_details": {"pix_key": "a@b.com"}}}
+    not decision.compliant
+    decision.message == "Tipo de chave PIX não especificado"
+}
+
+test_pix_requires_key {
+    decision := bacen_pix.decision with input as {"transaction": {"payment_type": "pix", "payment_details": {"pix_key_type": "email"}}}
+    not decision.compliant
+    decision.message == "Chave PIX não especificada"
+}
+
+test_pix_with_key_is_compliant {
+    bacen_pix.decision.compliant with input as {
+        "transaction": {"payment_type": "pix", "payment_details": {"pix_key_type": "email", "pix_key": "a@b.com"}}
+    }
+}
+
+# ---------------------------------------------------------
+# PSD2 - SCA
+# ---------------------------------------------------------
+test_sca_not_required_below_threshold {
+    psd2_sca.decision.compliant with input as {"transaction": {"currency": "EUR", "amount": 25, "mfa_level": "low"}}
+}
+
+test_sca_requires_high_mfa {
+    not psd2_sca.decision.compliant with input as {"transaction": {"currency": "EUR", "amount": 100, "mfa_level": "medium"}}
+}
+
+test_sca_card_requires_3ds {
+    decision := psd2_sca.decision with input as {
+        "transaction": {"currency": "EUR", "amount": 100, "mfa_level": "high", "payment_type": "card", "three_ds_data": null}
+    }
+    decision.message == "Dados 3D Secure requeridos por PSD2"
+}
+
+test_sca_threshold_from_data {
+    psd2_sca.decision.compliant with input as {"transaction": {"currency": "EUR", "amount": 40, "mfa_level": "low"}}
+        with data.innovabiz.payment_gateway.config.psd2_sca.amount_threshold as 50
+}
+
+test_sca_exemption_waives_high_mfa {
+    psd2_sca.decision.compliant with input as {"transaction": {
+        "currency": "EUR", "amount": 200, "mfa_level": "low",
+        "sca_exemption": {"exemption": "tra", "outcome": "exempted", "reason": "TRA"}
+    }}
+}
+
+test_sca_required_below_threshold_without_exemption {
+    decision := psd2_sca.decision with input as {"transaction": {
+        "currency": "EUR", "amount": 20, "mfa_level": "low",
+        "sca_exemption": {"exemption": "none", "outcome": "sca_required", "reason": "limite de baixo valor atingido"}
+    }}
+    not decision.compliant
+    decision.message == "SCA requerido: limite de baixo valor atingido"
+}
+
+# ---------------------------------------------------------
+# PCI DSS
+# ---------------------------------------------------------
+test_pci_rejects_full_pan {
+    not pci_dss.decision.compliant with input as {
+        "transaction": {"payment_type": "card", "payment_details": {"card": {"full_number": "4111111111111111"}}}
+    }
+}
+
+test_pci_requires_token_or_truncated_pan {
+    decision := pci_dss.decision with input as {"transaction": {"payment_type": "card", "payment_details": {"card": {}}}}
+    decision.message == "Token ou PAN truncado requerido por PCI DSS"
+}
+
+test_pci_tokenized_card_is_compliant {
+    pci_dss.decision.compliant with input as {
+        "transaction": {"payment_type": "card", "payment_details": {"card": {"token": "tok_123"}}}
+    }
+}
@@ -0,0 +1,40 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// WithBudget limita o contexto a budget a partir de agora; um prazo já mais curto
+// no contexto prevalece, e budget 0 mantém o contexto sem alteração
+func WithBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, budget)
+}
+
+// Remaining retorna o tempo até ao prazo do contexto; ok é false se não houver prazo
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// AttemptTimeout retorna o timeout de uma tentativa: o timeout da política, reduzido
+// ao orçamento restante do contexto para que nenhuma chamada ultrapasse o prazo do pedido
+func AttemptTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	if remaining, ok := Remaining(ctx); ok && (timeout <= 0 || remaining < timeout) {
+		return remaining
+	}
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	return timeout
+}
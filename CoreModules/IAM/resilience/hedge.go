@@ -0,0 +1,77 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// hedgeResult é o resultado de uma chamada lançada por Hedge
+type hedgeResult[T any] struct {
+	value T
+	err   error
+}
+
+// Hedge executa fn e, se não houver resposta em HedgeDelay, lança até MaxHedges
+// chamadas paralelas; a primeira a ter sucesso ganha e as restantes são canceladas.
+// Se todas as chamadas lançadas falharem, Hedge devolve o último erro sem lançar
+// mais: as falhas são repetidas por fn. Só deve ser usado em operações idempotentes
+// (consultas); com HedgeDelay 0 executa fn uma vez.
+// As chamadas partilham o orçamento da política; o prazo de cada uma fica a cargo de
+// fn, normalmente DoHTTP ou Do com a mesma política.
+func Hedge[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	policy = policy.normalized()
+	calls := 1
+	if policy.HedgeDelay > 0 {
+		calls += policy.MaxHedges
+	}
+
+	ctx, cancel := WithBudget(ctx, policy.Budget)
+	defer cancel()
+
+	results := make(chan hedgeResult[T], calls)
+	launch := func() {
+		go func() {
+			value, err := fn(ctx)
+			results <- hedgeResult[T]{value: value, err: err}
+		}()
+	}
+
+	launch()
+	launched, finished := 1, 0
+
+	var timer <-chan time.Time
+	if launched < calls {
+		timer = time.After(policy.HedgeDelay)
+	}
+
+	var zero T
+	for {
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-timer:
+			launch()
+			launched++
+			timer = nil
+			if launched < calls {
+				timer = time.After(policy.HedgeDelay)
+			}
+		case result := <-results:
+			finished++
+			if result.err == nil {
+				return result.value, nil
+			}
+			if finished == launched {
+				return zero, unwrapPermanent(result.err)
+			}
+		}
+	}
+}
+
+// unwrapPermanent remove o marcador Permanent do erro
+func unwrapPermanent(err error) error {
+	if permanent, ok := err.(*permanentError); ok {
+		return permanent.err
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IdempotencyKeyHeader torna repetíveis os pedidos POST e PATCH
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// MaxResponseBytes limita o corpo lido por DoHTTP
+const MaxResponseBytes = 10 << 20
+
+// NewHTTPClient cria um cliente cujo timeout é o da política; as chamadas feitas por
+// DoHTTP usam ainda o prazo de cada tentativa e o orçamento do contexto
+func NewHTTPClient(policy Policy) *http.Client {
+	return &http.Client{Timeout: policy.normalized().Timeout}
+}
+
+// retryableStatusError guarda a resposta de um estado repetível
+type retryableStatusError struct {
+	resp *http.Response
+}
+
+func (e *retryableStatusError) Error() string {
+	return fmt.Sprintf("dependência respondeu %d", e.resp.StatusCode)
+}
+
+// DoHTTP executa o pedido criado por build segundo a política. Falhas de rede e
+// respostas 408, 429, 500, 502, 503 e 504 são repetidas (respeitando Retry-After),
+// mas só para métodos idempotentes, pedidos com Idempotency-Key ou políticas com
+// RetryNonIdempotent; os outros pedidos só repetem 429, que não chega a ser processado.
+// build é chamado a cada tentativa, com o contexto da tentativa, para recriar o corpo.
+//
+// O corpo da resposta é lido dentro do prazo da tentativa e devolvido em memória.
+// Esgotadas as tentativas num estado repetível, a última resposta é devolvida sem
+// erro para que o chamador trate o estado como antes.
+func DoHTTP(ctx context.Context, client *http.Client, policy Policy, build func(ctx context.Context) (*http.Request, error), opts ...Option) (*http.Response, error) {
+	if client == nil {
+		client = NewHTTPClient(policy)
+	}
+
+	var resp *http.Response
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		req, err := build(ctx)
+		if err != nil {
+			return Permanent(err)
+		}
+		retryable := policy.RetryNonIdempotent || isIdempotent(req)
+
+		attemptResp, err := client.Do(req)
+		if err != nil {
+			if !retryable || ctx.Err() == context.Canceled {
+				return Permanent(err)
+			}
+			return err
+		}
+
+		body, err := io.ReadAll(io.LimitReader(attemptResp.Body, MaxResponseBytes))
+		attemptResp.Body.Close()
+		if err != nil {
+			if !retryable {
+				return Permanent(err)
+			}
+			return err
+		}
+		attemptResp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !isRetryableStatus(attemptResp.StatusCode) {
+			resp = attemptResp
+			return nil
+		}
+
+		statusErr := &retryableStatusError{resp: attemptResp}
+		if !retryable && attemptResp.StatusCode != http.StatusTooManyRequests {
+			return Permanent(statusErr)
+		}
+		if delay, ok := retryAfterDelay(attemptResp.Header.Get("Retry-After")); ok {
+			return RetryAfter(statusErr, delay)
+		}
+		return statusErr
+	}, opts...)
+
+	var statusErr *retryableStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// isIdempotent indica se o pedido pode ser repetido sem efeitos duplicados
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// isRetryableStatus indica os estados transitórios
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfterDelay interpreta Retry-After em segundos ou como data HTTP
+func retryAfterDelay(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
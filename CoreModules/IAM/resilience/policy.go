@@ -0,0 +1,294 @@
+// Package resilience fornece as políticas partilhadas de timeout, retry com jitter,
+// orçamento de prazo e hedging usadas nas chamadas HTTP a provedores de crédito,
+// PSPs e cobradores da plataforma INNOVABIZ.
+//
+// Cada dependência externa tem a sua política, carregada de um ficheiro YAML e
+// sobreposta por variáveis de ambiente, em vez de timeouts fixos em cada conector.
+package resilience
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dependências conhecidas, usadas como chave no ficheiro de políticas
+const (
+	DependencySerasa      = "serasa"
+	DependencyBoleto      = "boleto"
+	DependencyOpenFinance = "open-finance"
+	DependencyCallbacks   = "payment-callbacks"
+	DependencyTrustGuard  = "trustguard"
+)
+
+// Variáveis de ambiente do carregador de políticas
+const (
+	EnvConfigPath = "RESILIENCE_CONFIG" // Caminho do ficheiro YAML de políticas
+	envPrefix     = "RESILIENCE_"       // RESILIENCE_<DEPENDENCIA>_<CAMPO>, por exemplo RESILIENCE_SERASA_TIMEOUT=20s
+)
+
+// Valores padrão da política
+const (
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+// Policy define como uma dependência externa é chamada
+type Policy struct {
+	// Timeout limita cada tentativa
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+
+	// Budget limita o conjunto das tentativas, incluindo as esperas; 0 usa apenas o prazo do contexto
+	Budget time.Duration `yaml:"budget" json:"budget"`
+
+	// MaxAttempts inclui a primeira tentativa
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts"`
+
+	// Espera entre tentativas: InitialBackoff multiplicado por Multiplier a cada tentativa, até MaxBackoff
+	InitialBackoff time.Duration `yaml:"initialBackoff" json:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff" json:"maxBackoff"`
+	Multiplier     float64       `yaml:"multiplier" json:"multiplier"`
+
+	// Jitter é a fração aleatória (0 a 1) retirada de cada espera, para evitar picos sincronizados
+	Jitter float64 `yaml:"jitter" json:"jitter"`
+
+	// HedgeDelay é a espera antes de lançar uma chamada paralela; 0 desativa o hedging
+	HedgeDelay time.Duration `yaml:"hedgeDelay" json:"hedgeDelay"`
+	MaxHedges  int           `yaml:"maxHedges" json:"maxHedges"` // Chamadas paralelas além da primeira
+
+	// RetryNonIdempotent permite repetir pedidos POST/PATCH sem Idempotency-Key
+	RetryNonIdempotent bool `yaml:"retryNonIdempotent" json:"retryNonIdempotent"`
+}
+
+// DefaultPolicy retorna a política padrão
+func DefaultPolicy() Policy {
+	return Policy{
+		Timeout:        DefaultTimeout,
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+}
+
+// Merge retorna a política com os campos definidos em override; os restantes mantêm-se
+func (p Policy) Merge(override Policy) Policy {
+	if override.Timeout > 0 {
+		p.Timeout = override.Timeout
+	}
+	if override.Budget > 0 {
+		p.Budget = override.Budget
+	}
+	if override.MaxAttempts > 0 {
+		p.MaxAttempts = override.MaxAttempts
+	}
+	if override.InitialBackoff > 0 {
+		p.InitialBackoff = override.InitialBackoff
+	}
+	if override.MaxBackoff > 0 {
+		p.MaxBackoff = override.MaxBackoff
+	}
+	if override.Multiplier > 0 {
+		p.Multiplier = override.Multiplier
+	}
+	if override.Jitter > 0 {
+		p.Jitter = override.Jitter
+	}
+	if override.HedgeDelay > 0 {
+		p.HedgeDelay = override.HedgeDelay
+	}
+	if override.MaxHedges > 0 {
+		p.MaxHedges = override.MaxHedges
+	}
+	if override.RetryNonIdempotent {
+		p.RetryNonIdempotent = true
+	}
+	return p
+}
+
+// normalized preenche os campos em falta com os valores padrão
+func (p Policy) normalized() Policy {
+	p = DefaultPolicy().Merge(p)
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p
+}
+
+// Validate verifica a coerência da política
+func (p Policy) Validate() error {
+	if p.Timeout < 0 || p.Budget < 0 || p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.HedgeDelay < 0 {
+		return fmt.Errorf("durações da política não podem ser negativas")
+	}
+	if p.MaxAttempts < 0 || p.MaxHedges < 0 {
+		return fmt.Errorf("maxAttempts e maxHedges não podem ser negativos")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter deve estar entre 0 e 1")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("multiplier deve ser maior ou igual a 1")
+	}
+	return nil
+}
+
+// Policies agrupa a política padrão e as políticas por dependência
+type Policies struct {
+	Default      Policy            `yaml:"default" json:"default"`
+	Dependencies map[string]Policy `yaml:"dependencies" json:"dependencies"`
+}
+
+// NewPolicies cria um conjunto apenas com a política padrão
+func NewPolicies() *Policies {
+	return &Policies{Default: DefaultPolicy(), Dependencies: make(map[string]Policy)}
+}
+
+// For retorna a política da dependência, herdando da política padrão os campos não definidos
+func (p *Policies) For(dependency string) Policy {
+	if p == nil {
+		return DefaultPolicy()
+	}
+	policy := DefaultPolicy().Merge(p.Default)
+	if override, ok := p.Dependencies[strings.ToLower(dependency)]; ok {
+		policy = policy.Merge(override)
+	}
+	return policy
+}
+
+// Has indica se a dependência tem uma política própria
+func (p *Policies) Has(dependency string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Dependencies[strings.ToLower(dependency)]
+	return ok
+}
+
+// Set define a política de uma dependência
+func (p *Policies) Set(dependency string, policy Policy) {
+	if p.Dependencies == nil {
+		p.Dependencies = make(map[string]Policy)
+	}
+	p.Dependencies[strings.ToLower(dependency)] = policy
+}
+
+// Validate verifica todas as políticas do conjunto
+func (p *Policies) Validate() error {
+	if err := p.Default.Validate(); err != nil {
+		return fmt.Errorf("política padrão: %w", err)
+	}
+	for name, policy := range p.Dependencies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("política %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ParsePolicies lê as políticas em YAML (ou JSON, com durações em texto como "2s")
+func ParsePolicies(data []byte) (*Policies, error) {
+	var parsed Policies
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("políticas de resiliência inválidas: %w", err)
+	}
+	if err := parsed.Validate(); err != nil {
+		return nil, err
+	}
+
+	policies := NewPolicies()
+	policies.Default = policies.Default.Merge(parsed.Default)
+	for name, policy := range parsed.Dependencies {
+		policies.Set(name, policy)
+	}
+	return policies, nil
+}
+
+// LoadPolicies carrega as políticas do ficheiro indicado
+func LoadPolicies(path string) (*Policies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler políticas de resiliência: %w", err)
+	}
+	return ParsePolicies(data)
+}
+
+// LoadPoliciesFromEnv carrega o ficheiro de RESILIENCE_CONFIG (se definido) e aplica as
+// sobreposições RESILIENCE_<DEPENDENCIA>_TIMEOUT, _BUDGET, _MAX_ATTEMPTS e _HEDGE_DELAY
+// para a política padrão (DEFAULT) e para cada dependência indicada
+func LoadPoliciesFromEnv(dependencies ...string) (*Policies, error) {
+	policies := NewPolicies()
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		loaded, err := LoadPolicies(path)
+		if err != nil {
+			return nil, err
+		}
+		policies = loaded
+	}
+
+	override, err := policyFromEnv("default")
+	if err != nil {
+		return nil, err
+	}
+	policies.Default = policies.Default.Merge(override)
+
+	for _, dependency := range dependencies {
+		override, err := policyFromEnv(dependency)
+		if err != nil {
+			return nil, err
+		}
+		if override == (Policy{}) {
+			continue
+		}
+		current := policies.Dependencies[strings.ToLower(dependency)]
+		policies.Set(dependency, current.Merge(override))
+	}
+
+	if err := policies.Validate(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// policyFromEnv lê as sobreposições de ambiente de uma dependência
+func policyFromEnv(dependency string) (Policy, error) {
+	prefix := envPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(dependency)) + "_"
+
+	var policy Policy
+	durations := map[string]*time.Duration{
+		"TIMEOUT":     &policy.Timeout,
+		"BUDGET":      &policy.Budget,
+		"HEDGE_DELAY": &policy.HedgeDelay,
+	}
+	for name, target := range durations {
+		value := os.Getenv(prefix + name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return Policy{}, fmt.Errorf("%s%s inválido: %w", prefix, name, err)
+		}
+		*target = parsed
+	}
+
+	if value := os.Getenv(prefix + "MAX_ATTEMPTS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return Policy{}, fmt.Errorf("%sMAX_ATTEMPTS inválido: %w", prefix, err)
+		}
+		policy.MaxAttempts = parsed
+	}
+	return policy, nil
+}
@@ -0,0 +1,152 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// permanentError marca um erro que não deve ser repetido
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marca o erro como definitivo: Do devolve-o sem novas tentativas
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent indica se o erro foi marcado como definitivo
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// retryAfterError indica a espera pedida pela dependência (por exemplo, Retry-After)
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter marca o erro como repetível após a espera indicada pela dependência
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// Attempt descreve uma tentativa falhada, passada ao OnRetry
+type Attempt struct {
+	Number int           // Tentativa que falhou, a partir de 1
+	Err    error         // Erro da tentativa
+	Delay  time.Duration // Espera até à próxima tentativa
+}
+
+// Option ajusta uma execução de Do
+type Option func(*options)
+
+type options struct {
+	onRetry func(Attempt)
+}
+
+// OnRetry regista uma função chamada antes de cada nova tentativa (útil para logs e métricas)
+func OnRetry(fn func(Attempt)) Option {
+	return func(o *options) { o.onRetry = fn }
+}
+
+// randFloat é substituível nos testes
+var randFloat = rand.Float64
+
+// Do executa fn segundo a política: cada tentativa recebe um contexto limitado por
+// Timeout e pelo orçamento restante; as falhas são repetidas com backoff exponencial
+// e jitter até MaxAttempts, exceto erros Permanent e o cancelamento do contexto.
+// Devolve o erro da última tentativa, sem o marcador Permanent.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error, opts ...Option) error {
+	policy = policy.normalized()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, cancel := WithBudget(ctx, policy.Budget)
+	defer cancel()
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := runAttempt(ctx, policy, fn)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return unwrapRetryAfter(err)
+		}
+
+		delay := jittered(backoff, policy.Jitter)
+		var hinted *retryAfterError
+		if errors.As(err, &hinted) && hinted.delay > delay {
+			delay = hinted.delay
+		}
+
+		// Sem orçamento para esperar e tentar de novo, a última falha é a resposta
+		if remaining, ok := Remaining(ctx); ok && remaining <= delay {
+			return unwrapRetryAfter(err)
+		}
+
+		if o.onRetry != nil {
+			o.onRetry(Attempt{Number: attempt, Err: err, Delay: delay})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return unwrapRetryAfter(err)
+		case <-timer.C:
+		}
+
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// runAttempt executa uma tentativa com o seu próprio prazo
+func runAttempt(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, AttemptTimeout(ctx, policy.Timeout))
+	defer cancel()
+	return fn(attemptCtx)
+}
+
+// jittered retira da espera uma fração aleatória até jitter
+func jittered(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return delay - time.Duration(float64(delay)*jitter*randFloat())
+}
+
+// unwrapRetryAfter remove a indicação de espera do erro final
+func unwrapRetryAfter(err error) error {
+	var hinted *retryAfterError
+	if errors.As(err, &hinted) && err == error(hinted) {
+		return hinted.err
+	}
+	return err
+}
@@ -0,0 +1,340 @@
+// Package resilience_test fornece testes unitários para as políticas partilhadas de
+// timeout, retry, orçamento de prazo e hedging
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/resilience"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastPolicy retorna uma política com esperas curtas para os testes
+func fastPolicy() resilience.Policy {
+	return resilience.Policy{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	var calls int
+	var retries []resilience.Attempt
+	err := resilience.Do(context.Background(), fastPolicy(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("indisponível")
+		}
+		return nil
+	}, resilience.OnRetry(func(attempt resilience.Attempt) {
+		retries = append(retries, attempt)
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	require.Len(t, retries, 2)
+	assert.Equal(t, 1, retries[0].Number)
+	assert.Equal(t, 2, retries[1].Number)
+}
+
+func TestDoStopsAtMaxAttempts(t *testing.T) {
+	failure := errors.New("indisponível")
+	var calls int
+	err := resilience.Do(context.Background(), fastPolicy(), func(ctx context.Context) error {
+		calls++
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoDoesNotRetryPermanentErrors(t *testing.T) {
+	rejected := errors.New("pedido rejeitado")
+	var calls int
+	err := resilience.Do(context.Background(), fastPolicy(), func(ctx context.Context) error {
+		calls++
+		return resilience.Permanent(rejected)
+	})
+
+	assert.Equal(t, rejected, err)
+	assert.False(t, resilience.IsPermanent(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoAppliesAttemptTimeout(t *testing.T) {
+	policy := fastPolicy()
+	policy.Timeout = 20 * time.Millisecond
+	policy.MaxAttempts = 2
+
+	var calls int
+	start := time.Now()
+	err := resilience.Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoRespectsBudget(t *testing.T) {
+	policy := fastPolicy()
+	policy.MaxAttempts = 10
+	policy.InitialBackoff = 40 * time.Millisecond
+	policy.MaxBackoff = 40 * time.Millisecond
+	policy.Budget = 100 * time.Millisecond
+
+	var calls int
+	start := time.Now()
+	err := resilience.Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.LessOrEqual(t, time.Until(deadline), policy.Budget)
+		return errors.New("indisponível")
+	})
+
+	require.Error(t, err)
+	assert.Less(t, calls, 10)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestDoStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := fastPolicy()
+	policy.MaxAttempts = 5
+	policy.InitialBackoff = time.Second
+	policy.MaxBackoff = time.Second
+
+	var calls int
+	err := resilience.Do(ctx, policy, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("indisponível")
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestAttemptTimeoutIsCappedByRemainingBudget(t *testing.T) {
+	ctx, cancel := resilience.WithBudget(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.LessOrEqual(t, resilience.AttemptTimeout(ctx, time.Second), 50*time.Millisecond)
+	assert.Equal(t, time.Second, resilience.AttemptTimeout(context.Background(), time.Second))
+
+	remaining, ok := resilience.Remaining(ctx)
+	assert.True(t, ok)
+	assert.LessOrEqual(t, remaining, 50*time.Millisecond)
+
+	_, ok = resilience.Remaining(context.Background())
+	assert.False(t, ok)
+}
+
+func TestHedgeReturnsFastestResult(t *testing.T) {
+	policy := fastPolicy()
+	policy.HedgeDelay = 10 * time.Millisecond
+	policy.MaxHedges = 1
+
+	var calls int32
+	value, err := resilience.Hedge(context.Background(), policy, func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// A primeira chamada fica presa até ser cancelada
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "hedge", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "hedge", value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHedgeWithoutDelayCallsOnce(t *testing.T) {
+	var calls int32
+	failure := errors.New("indisponível")
+	_, err := resilience.Hedge(context.Background(), fastPolicy(), func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDoHTTPRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	})
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoHTTPReturnsLastRetryableResponse(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	resp, err := resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoHTTPDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	resp, err := resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDoHTTPRetriesPostOnlyWithIdempotencyKey(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	post := func(idempotencyKey string) func(ctx context.Context) (*http.Request, error) {
+		return func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+			if err == nil && idempotencyKey != "" {
+				req.Header.Set(resilience.IdempotencyKeyHeader, idempotencyKey)
+			}
+			return req, err
+		}
+	}
+
+	resp, err := resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), post(""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), post("boleto-1"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoHTTPHonoursRetryAfter(t *testing.T) {
+	var calls int32
+	var first, second time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		second = time.Now()
+	}))
+	defer server.Close()
+
+	resp, err := resilience.DoHTTP(context.Background(), server.Client(), fastPolicy(), func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, second.Sub(first), time.Second)
+}
+
+func TestParsePoliciesInheritsDefault(t *testing.T) {
+	policies, err := resilience.ParsePolicies([]byte(`
+default:
+  timeout: 5s
+  maxAttempts: 4
+dependencies:
+  Serasa:
+    timeout: 2s
+    hedgeDelay: 300ms
+    maxHedges: 1
+`))
+	require.NoError(t, err)
+
+	serasa := policies.For(resilience.DependencySerasa)
+	assert.Equal(t, 2*time.Second, serasa.Timeout)
+	assert.Equal(t, 4, serasa.MaxAttempts)
+	assert.Equal(t, 300*time.Millisecond, serasa.HedgeDelay)
+	assert.Equal(t, resilience.DefaultInitialBackoff, serasa.InitialBackoff)
+	assert.True(t, policies.Has("serasa"))
+
+	boleto := policies.For(resilience.DependencyBoleto)
+	assert.Equal(t, 5*time.Second, boleto.Timeout)
+	assert.False(t, policies.Has(resilience.DependencyBoleto))
+}
+
+func TestParsePoliciesRejectsInvalidValues(t *testing.T) {
+	_, err := resilience.ParsePolicies([]byte("default:\n  jitter: 2\n"))
+	assert.Error(t, err)
+
+	_, err = resilience.ParsePolicies([]byte("dependencies:\n  boleto:\n    timeout: -1s\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadPoliciesFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("dependencies:\n  open-finance:\n    timeout: 15s\n"), 0o600))
+
+	t.Setenv(resilience.EnvConfigPath, path)
+	t.Setenv("RESILIENCE_DEFAULT_MAX_ATTEMPTS", "2")
+	t.Setenv("RESILIENCE_OPEN_FINANCE_BUDGET", "30s")
+
+	policies, err := resilience.LoadPoliciesFromEnv(resilience.DependencyOpenFinance)
+	require.NoError(t, err)
+
+	openFinance := policies.For(resilience.DependencyOpenFinance)
+	assert.Equal(t, 15*time.Second, openFinance.Timeout)
+	assert.Equal(t, 30*time.Second, openFinance.Budget)
+	assert.Equal(t, 2, openFinance.MaxAttempts)
+
+	t.Setenv("RESILIENCE_OPEN_FINANCE_TIMEOUT", "rápido")
+	_, err = resilience.LoadPoliciesFromEnv(resilience.DependencyOpenFinance)
+	assert.Error(t, err)
+}
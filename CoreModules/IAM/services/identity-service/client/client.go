@@ -0,0 +1,137 @@
+// Package client fornece um cliente Go tipado para a API REST do identity-service.
+//
+// Os tipos e operações em client.gen.go são gerados a partir de api/openapi.json;
+// após alterar rotas ou DTOs, execute go generate neste pacote.
+package client
+
+//go:generate go run ../cmd/openapi-gen -spec ../api/openapi.json -client client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client executa chamadas à API REST do identity-service
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+}
+
+// Option configura o cliente
+type Option func(*Client)
+
+// WithHTTPClient define o cliente HTTP usado nas chamadas
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTenantID define o tenant enviado em todas as chamadas
+func WithTenantID(tenantID uuid.UUID) Option {
+	return WithHeader("X-Tenant-ID", tenantID.String())
+}
+
+// WithUserID define o usuário autor das operações
+func WithUserID(userID uuid.UUID) Option {
+	return WithHeader("X-User-ID", userID.String())
+}
+
+// WithLanguage define o idioma das mensagens de erro
+func WithLanguage(language string) Option {
+	return WithHeader("Accept-Language", language)
+}
+
+// WithHeader define um cabeçalho enviado em todas as chamadas
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(name, value)
+	}
+}
+
+// New cria um cliente para a API servida no endereço base indicado
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		headers:    make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError representa uma resposta de erro da API
+type APIError struct {
+	StatusCode int
+	Body       ErrorResponse
+}
+
+// Error implementa a interface error
+func (e *APIError) Error() string {
+	if e.Body.Message != "" {
+		return fmt.Sprintf("identity-service: %d %s: %s", e.StatusCode, e.Body.Code, e.Body.Message)
+	}
+	return fmt.Sprintf("identity-service: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// do executa a chamada e decodifica a resposta quando o código esperado é recebido
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}, expected int) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("falha ao serializar requisição: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("falha ao criar requisição: %w", err)
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("falha na chamada %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		// Respostas sem o envelope de erro mantêm apenas o código HTTP
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr.Body)
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("falha ao decodificar resposta de %s %s: %w", method, path, err)
+	}
+	return nil
+}
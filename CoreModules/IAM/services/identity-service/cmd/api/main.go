@@ -2,13 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel"
@@ -21,10 +28,22 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"innovabiz/iam/identity-service/internal/application"
 	"innovabiz/iam/identity-service/internal/application/impl"
+	"innovabiz/iam/identity-service/internal/domain/event"
 	"innovabiz/iam/identity-service/internal/domain/events"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/infrastructure/archive"
+	"innovabiz/iam/identity-service/internal/infrastructure/cache"
+	"innovabiz/iam/identity-service/internal/infrastructure/kubernetes"
+	"innovabiz/iam/identity-service/internal/infrastructure/messaging"
+	"innovabiz/iam/identity-service/internal/infrastructure/notification"
+	"innovabiz/iam/identity-service/internal/infrastructure/oauth"
 	"innovabiz/iam/identity-service/internal/infrastructure/persistence/postgres"
+	"innovabiz/iam/identity-service/internal/infrastructure/saml"
 	"innovabiz/iam/identity-service/internal/interface/api/server"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
 )
 
 const (
@@ -40,6 +59,12 @@ func main() {
 
 	// Configurar logger
 	setupLogger()
+
+	// Subcomando de migração do esquema
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	log.Info().Msg("Iniciando serviço de identidade do INNOVABIZ IAM")
 
 	// Configurar OpenTelemetry
@@ -61,6 +86,11 @@ func main() {
 	dbConfig.ConnMaxLifetime = getEnvDuration("DB_CONN_MAX_LIFETIME", dbConfig.ConnMaxLifetime)
 	dbConfig.ConnMaxIdleTime = getEnvDuration("DB_CONN_MAX_IDLE_TIME", dbConfig.ConnMaxIdleTime)
 	
+	// Recusar o arranque em produção se o esquema divergir das migrações embutidas
+	if err := checkSchemaDrift(); err != nil {
+		log.Fatal().Err(err).Msg("Esquema da base de dados incompatível com esta versão do serviço")
+	}
+
 	log.Info().Msg("Conectando ao banco de dados PostgreSQL")
 	db, err := postgres.NewPostgresDB(dbConfig)
 	if err != nil {
@@ -70,28 +100,574 @@ func main() {
 
 	// Configurar repositórios
 	log.Info().Msg("Inicializando repositórios")
-	roleRepo := postgres.NewRoleRepository(db, log.With().Str("component", "RoleRepository").Logger())
+	var roleRepo repository.RoleRepository = postgres.NewRoleRepository(db, log.With().Str("component", "RoleRepository").Logger())
+	var permissionRepo repository.PermissionRepository = postgres.NewPermissionRepository(db)
 	// Configurar outros repositórios conforme necessário
 	// userRepo := postgres.NewUserRepository(db, log.With().Str("component", "UserRepository").Logger())
-	// permissionRepo := postgres.NewPermissionRepository(db, log.With().Str("component", "PermissionRepository").Logger())
 
 	// Configurar barramento de eventos
 	log.Info().Msg("Inicializando barramento de eventos")
 	eventBus := events.NewInMemoryEventBus(log.With().Str("component", "EventBus").Logger())
 	// Registrar listeners e publishers conforme necessário
 	
+	// Configurar publicação de eventos no Kafka para sincronização com RH/ITSM
+	var eventPublisher event.Publisher
+	if brokers := getEnv("KAFKA_BROKERS", ""); brokers != "" {
+		kafkaPublisher, err := messaging.NewKafkaPublisher(messaging.KafkaConfig{
+			Brokers:      strings.Split(brokers, ","),
+			TopicPrefix:  getEnv("KAFKA_TOPIC_PREFIX", ""),
+			WriteTimeout: getEnvDuration("KAFKA_WRITE_TIMEOUT", messaging.DefaultKafkaWriteTimeout),
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao configurar o publicador Kafka")
+		}
+		defer kafkaPublisher.Close()
+		eventPublisher = kafkaPublisher
+	}
+
+	// Configurar deteção de anomalias no fluxo de auditoria
+	// Os eventos de funções passam pelo analisador antes de seguirem para o barramento;
+	// os incidentes são publicados no Kafka para encaminhamento ao SIEM
+	var auditAnomalyService application.AuditAnomalyService
+	domainPublisher := event.Publisher(eventBus)
+	if getEnv("AUDIT_ANOMALY_DETECTION_ENABLED", "true") == "true" {
+		anomalyConfig := impl.DefaultAuditAnomalyConfig()
+		anomalyConfig.BusinessHoursStart = getEnvInt("AUDIT_BUSINESS_HOURS_START", anomalyConfig.BusinessHoursStart)
+		anomalyConfig.BusinessHoursEnd = getEnvInt("AUDIT_BUSINESS_HOURS_END", anomalyConfig.BusinessHoursEnd)
+		if timezone := getEnv("AUDIT_BUSINESS_HOURS_TIMEZONE", ""); timezone != "" {
+			location, err := time.LoadLocation(timezone)
+			if err != nil {
+				log.Fatal().Err(err).Str("timezone", timezone).Msg("Fuso horário do expediente inválido")
+			}
+			anomalyConfig.Location = location
+		}
+		if roleCodes := getEnv("AUDIT_PRIVILEGED_ROLE_CODES", ""); roleCodes != "" {
+			anomalyConfig.PrivilegedRoleCodes = strings.Split(roleCodes, ",")
+		}
+		anomalyConfig.MassRoleDeletionCount = getEnvInt("AUDIT_MASS_ROLE_DELETION_COUNT", anomalyConfig.MassRoleDeletionCount)
+		anomalyConfig.MassRoleDeletionWindow = getEnvDuration("AUDIT_MASS_ROLE_DELETION_WINDOW", anomalyConfig.MassRoleDeletionWindow)
+		anomalyConfig.NewAdminWindow = getEnvDuration("AUDIT_NEW_ADMIN_WINDOW", anomalyConfig.NewAdminWindow)
+
+		auditAnomalyService = impl.NewAuditAnomalyService(
+			postgres.NewSecurityIncidentRepository(db),
+			eventPublisher,
+			anomalyConfig,
+		)
+		domainPublisher = impl.NewAnomalyDetectingPublisher(eventBus, auditAnomalyService)
+	}
+
+	// Configurar a cache de leitura das funções e permissões no Redis
+	// As alterações publicadas no barramento, incluindo as de outras instâncias, invalidam as entradas
+	if redisAddrs := getEnv("REPOSITORY_CACHE_REDIS_ADDRS", ""); redisAddrs != "" {
+		redisClient := redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:    strings.Split(redisAddrs, ","),
+			Password: getEnv("REPOSITORY_CACHE_REDIS_PASSWORD", ""),
+		})
+		defer redisClient.Close()
+
+		cacheConfig := cache.DefaultConfig()
+		cacheConfig.KeyPrefix = getEnv("REPOSITORY_CACHE_KEY_PREFIX", cacheConfig.KeyPrefix)
+		cacheConfig.TTL = getEnvDuration("REPOSITORY_CACHE_TTL", cacheConfig.TTL)
+		cacheConfig.NegativeTTL = getEnvDuration("REPOSITORY_CACHE_NEGATIVE_TTL", cacheConfig.NegativeTTL)
+		cacheStore := cache.NewRedisStore(redisClient)
+
+		cachedRoleRepo := cache.NewRoleRepository(roleRepo, cacheStore, cacheConfig)
+		cachedPermissionRepo := cache.NewPermissionRepository(permissionRepo, cacheStore, cacheConfig, domainPublisher)
+		cacheInvalidator := cache.NewInvalidator(cachedRoleRepo, cachedPermissionRepo)
+		if err := cacheInvalidator.Attach(eventBus); err != nil {
+			log.Fatal().Err(err).Msg("Falha ao assinar a invalidação da cache dos repositórios")
+		}
+		defer cacheInvalidator.Close()
+
+		roleRepo = cachedRoleRepo
+		permissionRepo = cachedPermissionRepo
+		log.Info().Str("redis", redisAddrs).Msg("Cache de funções e permissões ativa")
+	}
+
 	// Configurar serviços
 	log.Info().Msg("Inicializando serviços de aplicação")
 	serviceFactory := impl.NewServiceFactory(
 		roleRepo,
 		// userRepo,
-		// permissionRepo,
-		eventBus,
+		permissionRepo,
+		domainPublisher,
 		log.With().Str("component", "ServiceFactory").Logger(),
 	)
 	
 	roleService := serviceFactory.NewRoleService()
 
+	// Configurar histórico imutável de funções
+	roleHistoryService := impl.NewRoleHistoryService(
+		postgres.NewRoleEventStore(db),
+		getEnvInt("ROLE_HISTORY_SNAPSHOT_INTERVAL", impl.DefaultRoleSnapshotInterval),
+	)
+	if roleServiceImpl, ok := roleService.(*impl.RoleServiceImpl); ok {
+		roleServiceImpl.SetHistoryService(roleHistoryService, db)
+	}
+
+	// Configurar os limites de permissões, aplicados nas atribuições e na avaliação do PDP
+	// As violações são registadas como incidentes de segurança e publicadas no Kafka para o SIEM
+	var permissionBoundaryService application.PermissionBoundaryService
+	if getEnv("PERMISSION_BOUNDARIES_ENABLED", "true") == "true" {
+		permissionBoundaryService = impl.NewPermissionBoundaryService(
+			postgres.NewPermissionBoundaryRepository(db),
+			postgres.NewSecurityIncidentRepository(db),
+			eventPublisher,
+			getEnvDuration("PERMISSION_BOUNDARY_CACHE_TTL", impl.DefaultPermissionBoundaryCacheTTL),
+		)
+		if roleServiceImpl, ok := roleService.(*impl.RoleServiceImpl); ok {
+			roleServiceImpl.SetBoundaryService(permissionBoundaryService)
+		}
+	}
+
+	// Configurar ciclo de vida de usuários e as transições automáticas
+	userLifecycleRepository := postgres.NewUserLifecycleRepository(db)
+	userLifecycleService := impl.NewUserLifecycleService(
+		userLifecycleRepository,
+		eventPublisher,
+		model.UserLifecyclePolicy{
+			InvitationTTLDays: getEnvInt("USER_INVITATION_TTL_DAYS", impl.DefaultInvitationTTLDays),
+		},
+	)
+	lifecycleCtx, stopLifecycle := context.WithCancel(context.Background())
+	defer stopLifecycle()
+	if getEnv("USER_LIFECYCLE_SCHEDULER_ENABLED", "true") == "true" {
+		scheduler := impl.NewUserLifecycleScheduler(
+			userLifecycleService,
+			getEnvDuration("USER_LIFECYCLE_SCHEDULER_INTERVAL", impl.DefaultUserLifecycleInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar operações em massa sobre usuários, executadas pelo agendador em lotes
+	// As transições e as atribuições passam pelo ciclo de vida e pelo serviço de funções
+	var bulkUserJobService application.BulkUserJobService
+	if getEnv("BULK_USER_OPERATIONS_ENABLED", "true") == "true" {
+		bulkUserJobService = impl.NewBulkUserJobService(
+			postgres.NewBulkUserJobRepository(db),
+			userLifecycleRepository,
+			userLifecycleService,
+			roleService,
+			impl.BulkUserJobConfig{
+				BatchSize: getEnvInt("BULK_USER_OPERATIONS_BATCH_SIZE", impl.DefaultBulkUserJobBatchSize),
+				MaxItems:  getEnvInt("BULK_USER_OPERATIONS_MAX_ITEMS", impl.DefaultBulkUserJobMaxItems),
+			},
+		)
+		scheduler := impl.NewBulkUserJobScheduler(
+			bulkUserJobService,
+			getEnvDuration("BULK_USER_OPERATIONS_SCHEDULER_INTERVAL", impl.DefaultBulkUserJobInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar pedidos de acesso em autoatendimento
+	accessRequestService := impl.NewAccessRequestService(
+		postgres.NewAccessRequestRepository(db),
+		roleService,
+		getEnvDuration("ACCESS_REQUEST_SLA", impl.DefaultAccessRequestSLA),
+	)
+
+	// Configurar biblioteca de modelos de função
+	roleTemplateService := impl.NewRoleTemplateService(postgres.NewRoleTemplateRepository(db), roleService)
+
+	// Configurar ligação de contas entre fornecedores de identidade
+	// Os logins federados com o email verificado de uma conta local passam a candidatos à ligação
+	var (
+		accountLinkService  application.AccountLinkService
+		accountLinkDetector application.AccountLinkCandidateDetector
+	)
+	if getEnv("ACCOUNT_LINKING_ENABLED", "true") == "true" {
+		accountLinkConfig := impl.DefaultAccountLinkConfig()
+		accountLinkConfig.CandidateTTL = getEnvDuration("ACCOUNT_LINK_CANDIDATE_TTL", accountLinkConfig.CandidateTTL)
+		accountLinkConfig.ReauthMaxAge = getEnvDuration("ACCOUNT_LINK_REAUTH_MAX_AGE", accountLinkConfig.ReauthMaxAge)
+		accountLinkService = impl.NewAccountLinkService(postgres.NewAccountLinkRepository(db), roleService, accountLinkConfig)
+		accountLinkDetector = accountLinkService
+	}
+
+	// Configurar a aceitação dos termos de serviço, das políticas de privacidade e dos consentimentos
+	// Com o bloqueio ativo, os logins SAML e sem senha só são concluídos depois de aceites os documentos obrigatórios
+	var legalConsentService application.LegalConsentService
+	if getEnv("LEGAL_CONSENT_ENABLED", "true") == "true" {
+		legalConsentConfig := impl.DefaultLegalConsentConfig()
+		legalConsentConfig.BlockLogin = getEnv("LEGAL_CONSENT_BLOCK_LOGIN", "true") == "true"
+		legalConsentService = impl.NewLegalConsentService(postgres.NewLegalConsentRepository(db), legalConsentConfig)
+	}
+
+	// Configurar o codec dos tokens emitidos pelo serviço (troca de tokens e contas de serviço)
+	// Os tokens são assinados com a mesma chave dos tokens de sessão, validada pelos serviços de destino;
+	// sendo uma chave simétrica, os serviços que a recebem conseguem também emitir tokens delegados
+	var tokenCodec *oauth.TokenCodec
+	if secret := getEnv("JWT_SECRET", ""); secret != "" {
+		codec, err := oauth.NewTokenCodec(oauth.Config{
+			Secret: []byte(secret),
+			Issuer: getEnv("JWT_ISSUER", ""),
+			Leeway: getEnvDuration("TOKEN_EXCHANGE_CLOCK_SKEW", 30*time.Second),
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao configurar o codec dos tokens")
+		}
+		tokenCodec = codec
+	}
+
+	// Configurar os atributos personalizados dos usuários, expostos às políticas ABAC e emitidos como claims
+	var userAttributeService application.UserAttributeService
+	if getEnv("USER_ATTRIBUTES_ENABLED", "true") == "true" {
+		userAttributeService = impl.NewUserAttributeService(
+			postgres.NewUserAttributeRepository(db),
+			getEnvDuration("USER_ATTRIBUTE_SCHEMA_CACHE_TTL", impl.DefaultUserAttributeSchemaCacheTTL),
+		)
+	}
+
+	// Configurar a troca de tokens para delegação entre serviços (RFC 8693)
+	var tokenExchangeService application.TokenExchangeService
+	if tokenCodec != nil && getEnv("TOKEN_EXCHANGE_ENABLED", "true") == "true" {
+		tokenExchangeConfig := impl.DefaultTokenExchangeConfig()
+		tokenExchangeConfig.Issuer = getEnv("JWT_ISSUER", "")
+		tokenExchangeConfig.MaxTTL = getEnvDuration("TOKEN_EXCHANGE_MAX_TTL", tokenExchangeConfig.MaxTTL)
+		if userAttributeService != nil {
+			tokenExchangeConfig.ClaimsResolver = userAttributeService
+		}
+		tokenExchangeService = impl.NewTokenExchangeService(postgres.NewTokenExchangeRepository(db), tokenCodec, tokenExchangeConfig)
+	}
+
+	// Configurar as contas de serviço dos workloads
+	// Os tokens emitidos na autenticação servem também de actor_token na troca de tokens
+	var serviceAccountService application.ServiceAccountService
+	if tokenCodec != nil && getEnv("SERVICE_ACCOUNTS_ENABLED", "true") == "true" {
+		serviceAccountConfig := impl.DefaultServiceAccountConfig()
+		serviceAccountConfig.Issuer = getEnv("JWT_ISSUER", "")
+		serviceAccountConfig.TokenTTL = getEnvDuration("SERVICE_ACCOUNT_TOKEN_TTL", serviceAccountConfig.TokenTTL)
+		serviceAccountConfig.DormantAfter = getEnvDuration("SERVICE_ACCOUNT_DORMANT_AFTER", serviceAccountConfig.DormantAfter)
+
+		// Os tokens das contas de serviço Kubernetes do cluster indicado são validados por TokenReview;
+		// sem KUBERNETES_API_SERVER é usada a API do cluster onde o serviço corre
+		if cluster := getEnv("KUBERNETES_CLUSTER_NAME", ""); cluster != "" {
+			reviewerConfig := kubernetes.Config{
+				APIServer: getEnv("KUBERNETES_API_SERVER", ""),
+				TokenFile: getEnv("KUBERNETES_TOKEN_FILE", kubernetes.InClusterTokenFile),
+				CAFile:    getEnv("KUBERNETES_CA_FILE", kubernetes.InClusterCAFile),
+				Timeout:   getEnvDuration("KUBERNETES_TOKEN_REVIEW_TIMEOUT", kubernetes.DefaultTimeout),
+			}
+			if reviewerConfig.APIServer == "" {
+				inCluster, err := kubernetes.InClusterConfig()
+				if err != nil {
+					log.Fatal().Err(err).Msg("Falha ao configurar a API do cluster Kubernetes")
+				}
+				reviewerConfig.APIServer = inCluster.APIServer
+			}
+			if audiences := getEnv("KUBERNETES_TOKEN_AUDIENCES", ""); audiences != "" {
+				reviewerConfig.Audiences = strings.Split(audiences, ",")
+			}
+			reviewer, err := kubernetes.NewTokenReviewer(reviewerConfig)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Falha ao configurar a validação dos tokens Kubernetes")
+			}
+			serviceAccountConfig.KubernetesClusters = map[string]application.KubernetesTokenReviewer{cluster: reviewer}
+		}
+		serviceAccountService = impl.NewServiceAccountService(postgres.NewServiceAccountRepository(db), tokenCodec, serviceAccountConfig)
+	}
+
+	// Configurar federação SAML 2.0 quando a chave do fornecedor de serviço estiver disponível
+	var samlFederationService application.SAMLFederationService
+	if keyFile, certFile := getEnv("SAML_SP_KEY_FILE", ""), getEnv("SAML_SP_CERT_FILE", ""); keyFile != "" && certFile != "" {
+		serviceProvider, err := saml.NewServiceProvider(saml.Config{
+			BaseURL:           getEnv("SAML_SP_BASE_URL", "http://localhost:8080/api/v1"),
+			KeyFile:           keyFile,
+			CertificateFile:   certFile,
+			SignAuthnRequests: getEnv("SAML_SP_SIGN_AUTHN_REQUESTS", "true") == "true",
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao configurar o fornecedor de serviço SAML")
+		}
+		samlFederationService = impl.NewSAMLFederationService(
+			postgres.NewSAMLFederationRepository(db),
+			serviceProvider,
+			roleService,
+			accountLinkDetector,
+			legalConsentService,
+			getEnvDuration("SAML_AUTHN_REQUEST_TTL", impl.DefaultSAMLAuthnRequestTTL),
+		)
+	}
+
+	// Configurar exportação de dados de tenants quando a chave de assinatura dos manifestos estiver disponível
+	var tenantExportService application.TenantExportService
+	if keyFile := getEnv("TENANT_EXPORT_SIGNING_KEY_FILE", ""); keyFile != "" {
+		signingKey, err := loadTenantExportSigningKey(keyFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao carregar a chave de assinatura das exportações")
+		}
+		exportArchive, err := archive.NewFilesystem(getEnv("TENANT_EXPORT_DIR", "/var/lib/innovabiz/iam/exports"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao configurar o arquivo das exportações")
+		}
+		tenantExportService = impl.NewTenantExportService(
+			postgres.NewTenantExportRepository(db),
+			exportArchive,
+			impl.TenantExportConfig{
+				BatchSize:           getEnvInt("TENANT_EXPORT_BATCH_SIZE", impl.DefaultTenantExportBatchSize),
+				SigningKey:          signingKey,
+				KeyID:               getEnv("TENANT_EXPORT_KEY_ID", "tenant-export-1"),
+				PseudonymizationKey: []byte(getEnv("TENANT_EXPORT_PSEUDONYMIZATION_KEY", "")),
+			},
+		)
+		scheduler := impl.NewTenantExportScheduler(
+			tenantExportService,
+			getEnvDuration("TENANT_EXPORT_SCHEDULER_INTERVAL", impl.DefaultTenantExportInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar auditoria das decisões de autorização
+	// As negações são sempre registadas; as permissões são amostradas e gravadas em lote
+	var permissionDecisionService application.PermissionDecisionAuditService
+	if getEnv("PERMISSION_DECISION_AUDIT_ENABLED", "true") == "true" {
+		decisionConfig := impl.DefaultPermissionDecisionAuditConfig()
+		decisionConfig.AllowSampleRate = getEnvFloat("PERMISSION_DECISION_ALLOW_SAMPLE_RATE", decisionConfig.AllowSampleRate)
+		decisionConfig.BatchSize = getEnvInt("PERMISSION_DECISION_BATCH_SIZE", decisionConfig.BatchSize)
+		decisionConfig.MaxPending = getEnvInt("PERMISSION_DECISION_MAX_PENDING", decisionConfig.MaxPending)
+		permissionDecisionService = impl.NewPermissionDecisionAuditService(
+			postgres.NewPermissionDecisionRepository(db),
+			decisionConfig,
+		)
+		flusher := impl.NewPermissionDecisionAuditFlusher(
+			permissionDecisionService,
+			getEnvDuration("PERMISSION_DECISION_FLUSH_INTERVAL", impl.DefaultPermissionDecisionFlushInterval),
+		)
+		go flusher.Start(lifecycleCtx)
+	}
+
+	// Configurar a consulta dos registos de auditoria pelas equipas de compliance
+	var auditLogService application.AuditLogService
+	if getEnv("AUDIT_LOG_VIEWER_ENABLED", "true") == "true" {
+		auditLogConfig := impl.DefaultAuditLogConfig()
+		auditLogConfig.PageSize = getEnvInt("AUDIT_LOG_PAGE_SIZE", auditLogConfig.PageSize)
+		auditLogConfig.MaxPageSize = getEnvInt("AUDIT_LOG_MAX_PAGE_SIZE", auditLogConfig.MaxPageSize)
+		auditLogConfig.MaxExportRows = getEnvInt("AUDIT_LOG_MAX_EXPORT_ROWS", auditLogConfig.MaxExportRows)
+		auditLogService = impl.NewAuditLogService(postgres.NewAuditLogRepository(db), auditLogConfig)
+	}
+
+	// Configurar sugestões de função mineradas das permissões diretas dos usuários
+	var roleMiningService application.RoleMiningService
+	if getEnv("ROLE_MINING_ENABLED", "true") == "true" {
+		miningConfig := impl.DefaultRoleMiningConfig()
+		miningConfig.MinUsers = getEnvInt("ROLE_MINING_MIN_USERS", miningConfig.MinUsers)
+		miningConfig.MinPermissions = getEnvInt("ROLE_MINING_MIN_PERMISSIONS", miningConfig.MinPermissions)
+		miningConfig.MinSimilarity = getEnvFloat("ROLE_MINING_MIN_SIMILARITY", miningConfig.MinSimilarity)
+		miningConfig.MaxSuggestions = getEnvInt("ROLE_MINING_MAX_SUGGESTIONS", miningConfig.MaxSuggestions)
+		roleMiningService = impl.NewRoleMiningService(
+			postgres.NewRoleMiningRepository(db),
+			roleService,
+			miningConfig,
+		)
+		scheduler := impl.NewRoleMiningScheduler(
+			roleMiningService,
+			getEnvDuration("ROLE_MINING_INTERVAL", impl.DefaultRoleMiningInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar as notificações de logins suspeitos quando a chave de assinatura estiver disponível
+	// O email usa o servidor SMTP configurado; o SMS e o push são entregues a webhooks assinados
+	var loginNotificationService application.LoginNotificationService
+	if signingKey := getEnv("LOGIN_NOTIFICATION_SIGNING_KEY", ""); signingKey != "" {
+		if len(signingKey) < impl.MinLoginNotificationSigningKeySize {
+			log.Fatal().Int("min_size", impl.MinLoginNotificationSigningKeySize).Msg("Chave de assinatura das notificações de login demasiado curta")
+		}
+		senders := make(map[model.LoginNotificationChannel]application.LoginNotificationSender)
+		if getEnv("SMTP_HOST", "") != "" {
+			smtpSender, err := notification.NewSMTPSender(notification.SMTPConfig{
+				Host:         getEnv("SMTP_HOST", ""),
+				Port:         getEnvInt("SMTP_PORT", 587),
+				Username:     getEnv("SMTP_USERNAME", ""),
+				Password:     getEnv("SMTP_PASSWORD", ""),
+				From:         getEnv("SMTP_FROM", ""),
+				SkipStartTLS: getEnv("SMTP_SKIP_STARTTLS", "false") == "true",
+			})
+			if err != nil {
+				log.Fatal().Err(err).Msg("Falha ao configurar o envio de emails das notificações de login")
+			}
+			senders[model.LoginNotificationChannelEmail] = notification.NewEmailLoginNotificationSender(smtpSender)
+		}
+		for channel, prefix := range map[model.LoginNotificationChannel]string{
+			model.LoginNotificationChannelSMS:  "LOGIN_NOTIFICATION_SMS_WEBHOOK",
+			model.LoginNotificationChannelPush: "LOGIN_NOTIFICATION_PUSH_WEBHOOK",
+		} {
+			webhookURL := getEnv(prefix+"_URL", "")
+			if webhookURL == "" {
+				continue
+			}
+			webhookSender, err := notification.NewWebhookLoginNotificationSender(notification.WebhookConfig{
+				URL:     webhookURL,
+				Secret:  []byte(getEnv(prefix+"_SECRET", "")),
+				Timeout: getEnvDuration(prefix+"_TIMEOUT", 0),
+			})
+			if err != nil {
+				log.Fatal().Err(err).Str("channel", string(channel)).Msg("Falha ao configurar o webhook das notificações de login")
+			}
+			senders[channel] = webhookSender
+		}
+		loginNotificationConfig := impl.DefaultLoginNotificationConfig()
+		loginNotificationConfig.SigningKey = []byte(signingKey)
+		loginNotificationConfig.ResponseURL = getEnv("LOGIN_NOTIFICATION_RESPONSE_URL", "http://localhost:3000/auth/not-me")
+		loginNotificationConfig.CredentialResetURL = getEnv("LOGIN_NOTIFICATION_CREDENTIAL_RESET_URL", "http://localhost:3000/auth/reset")
+		loginNotificationConfig.ResponseTTL = getEnvDuration("LOGIN_NOTIFICATION_RESPONSE_TTL", loginNotificationConfig.ResponseTTL)
+		loginNotificationConfig.CredentialResetTTL = getEnvDuration("LOGIN_NOTIFICATION_CREDENTIAL_RESET_TTL", loginNotificationConfig.CredentialResetTTL)
+		loginNotificationConfig.ContextRetention = getEnvDuration("LOGIN_NOTIFICATION_CONTEXT_RETENTION", loginNotificationConfig.ContextRetention)
+		loginNotificationService = impl.NewLoginNotificationService(
+			postgres.NewLoginNotificationRepository(db),
+			senders,
+			loginNotificationConfig,
+		)
+	}
+
+	// Configurar o registo de dispositivos quando a chave das impressões estiver disponível
+	// Os dispositivos partilhados por várias contas são registados como incidentes e publicados no Kafka para o SIEM
+	var deviceService application.DeviceService
+	if signingKey := getEnv("DEVICE_SIGNING_KEY", ""); signingKey != "" {
+		if len(signingKey) < impl.MinDeviceSigningKeySize {
+			log.Fatal().Int("min_size", impl.MinDeviceSigningKeySize).Msg("Chave das impressões dos dispositivos demasiado curta")
+		}
+		deviceConfig := impl.DefaultDeviceConfig()
+		deviceConfig.SigningKey = []byte(signingKey)
+		deviceConfig.ReuseWindow = getEnvDuration("DEVICE_REUSE_WINDOW", deviceConfig.ReuseWindow)
+		deviceService = impl.NewDeviceService(
+			postgres.NewDeviceRepository(db),
+			postgres.NewSecurityIncidentRepository(db),
+			eventPublisher,
+			deviceConfig,
+		)
+	}
+
+	// Configurar autenticação sem senha quando a chave de assinatura dos links estiver disponível
+	// Os links e os códigos são enviados por email através do servidor SMTP configurado
+	var passwordlessService application.PasswordlessService
+	if signingKey := getEnv("PASSWORDLESS_SIGNING_KEY", ""); signingKey != "" {
+		if len(signingKey) < impl.MinPasswordlessSigningKeySize {
+			log.Fatal().Int("min_size", impl.MinPasswordlessSigningKeySize).Msg("Chave de assinatura da autenticação sem senha demasiado curta")
+		}
+		sender, err := notification.NewSMTPSender(notification.SMTPConfig{
+			Host:         getEnv("SMTP_HOST", ""),
+			Port:         getEnvInt("SMTP_PORT", 587),
+			Username:     getEnv("SMTP_USERNAME", ""),
+			Password:     getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("SMTP_FROM", ""),
+			SkipStartTLS: getEnv("SMTP_SKIP_STARTTLS", "false") == "true",
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao configurar o envio de emails da autenticação sem senha")
+		}
+		passwordlessConfig := impl.DefaultPasswordlessConfig()
+		passwordlessConfig.SigningKey = []byte(signingKey)
+		passwordlessConfig.LinkBaseURL = getEnv("PASSWORDLESS_LINK_BASE_URL", "http://localhost:3000/auth/passwordless")
+		passwordlessConfig.MagicLinkTTL = getEnvDuration("PASSWORDLESS_MAGIC_LINK_TTL", passwordlessConfig.MagicLinkTTL)
+		passwordlessConfig.OTPTTL = getEnvDuration("PASSWORDLESS_OTP_TTL", passwordlessConfig.OTPTTL)
+		passwordlessConfig.OTPDigits = getEnvInt("PASSWORDLESS_OTP_DIGITS", passwordlessConfig.OTPDigits)
+		passwordlessConfig.MaxAttempts = getEnvInt("PASSWORDLESS_MAX_ATTEMPTS", passwordlessConfig.MaxAttempts)
+		passwordlessConfig.RateLimitWindow = getEnvDuration("PASSWORDLESS_RATE_LIMIT_WINDOW", passwordlessConfig.RateLimitWindow)
+		passwordlessConfig.MaxPerEmail = getEnvInt("PASSWORDLESS_MAX_PER_EMAIL", passwordlessConfig.MaxPerEmail)
+		passwordlessConfig.MaxPerIP = getEnvInt("PASSWORDLESS_MAX_PER_IP", passwordlessConfig.MaxPerIP)
+		var loginObservers application.LoginObservers
+		if loginNotificationService != nil {
+			loginObservers = append(loginObservers, loginNotificationService)
+		}
+		if deviceService != nil {
+			loginObservers = append(loginObservers, deviceService)
+		}
+		if len(loginObservers) > 0 {
+			passwordlessConfig.LoginObserver = loginObservers
+		}
+		passwordlessService = impl.NewPasswordlessService(
+			postgres.NewPasswordlessRepository(db),
+			sender,
+			legalConsentService,
+			passwordlessConfig,
+		)
+	}
+
+	// Configurar as políticas de rede por tenant e os acessos de emergência
+	var networkPolicyService application.NetworkPolicyService
+	if getEnv("NETWORK_POLICY_ENABLED", "true") == "true" {
+		networkPolicyConfig := impl.DefaultNetworkPolicyConfig()
+		networkPolicyConfig.CacheTTL = getEnvDuration("NETWORK_POLICY_CACHE_TTL", networkPolicyConfig.CacheTTL)
+		networkPolicyConfig.MaxCachedDecisions = getEnvInt("NETWORK_POLICY_MAX_CACHED_DECISIONS", networkPolicyConfig.MaxCachedDecisions)
+		networkPolicyConfig.DefaultBreakGlassDuration = getEnvDuration("NETWORK_POLICY_BREAK_GLASS_DURATION", networkPolicyConfig.DefaultBreakGlassDuration)
+		networkPolicyConfig.MaxBreakGlassDuration = getEnvDuration("NETWORK_POLICY_BREAK_GLASS_MAX_DURATION", networkPolicyConfig.MaxBreakGlassDuration)
+		networkPolicyService = impl.NewNetworkPolicyService(
+			postgres.NewNetworkPolicyRepository(db),
+			networkPolicyConfig,
+		)
+	}
+
+	// Configurar as políticas de sessão por tenant (validade dos tokens, duração e inatividade das sessões)
+	var sessionPolicyService application.SessionPolicyService
+	if getEnv("SESSION_POLICY_ENABLED", "true") == "true" {
+		sessionPolicyConfig := impl.DefaultSessionPolicyConfig()
+		sessionPolicyConfig.CacheTTL = getEnvDuration("SESSION_POLICY_CACHE_TTL", sessionPolicyConfig.CacheTTL)
+		sessionPolicyConfig.ClockSkew = getEnvDuration("SESSION_POLICY_CLOCK_SKEW", sessionPolicyConfig.ClockSkew)
+		sessionPolicyConfig.ActivityWriteInterval = getEnvDuration("SESSION_POLICY_ACTIVITY_WRITE_INTERVAL", sessionPolicyConfig.ActivityWriteInterval)
+		sessionPolicyConfig.MaxTrackedSessions = getEnvInt("SESSION_POLICY_MAX_TRACKED_SESSIONS", sessionPolicyConfig.MaxTrackedSessions)
+		sessionPolicyService = impl.NewSessionPolicyService(
+			postgres.NewSessionPolicyRepository(db),
+			sessionPolicyConfig,
+		)
+	}
+
+	// Configurar as políticas de acesso às chaves dos metadados das funções, definidas no catálogo de permissões
+	var roleMetadataPolicyService application.RoleMetadataPolicyService
+	if getEnv("ROLE_METADATA_POLICIES_ENABLED", "true") == "true" {
+		roleMetadataPolicyService = impl.NewRoleMetadataPolicyService(
+			postgres.NewRoleMetadataPolicyRepository(db),
+			getEnvDuration("ROLE_METADATA_POLICY_CACHE_TTL", impl.DefaultRoleMetadataPolicyCacheTTL),
+		)
+	}
+
+	// Configurar as contas de emergência (break-glass) e as suas desativações automáticas
+	var emergencyAccessService application.EmergencyAccessService
+	if getEnv("EMERGENCY_ACCESS_ENABLED", "true") == "true" {
+		emergencyAccessConfig := impl.DefaultEmergencyAccessConfig()
+		emergencyAccessConfig.CacheTTL = getEnvDuration("EMERGENCY_ACCESS_CACHE_TTL", emergencyAccessConfig.CacheTTL)
+		emergencyAccessConfig.DefaultActivationDuration = getEnvDuration("EMERGENCY_ACCESS_DEFAULT_DURATION", emergencyAccessConfig.DefaultActivationDuration)
+		emergencyAccessConfig.MaxActivationDuration = getEnvDuration("EMERGENCY_ACCESS_MAX_DURATION", emergencyAccessConfig.MaxActivationDuration)
+		emergencyAccessConfig.ApprovalTimeout = getEnvDuration("EMERGENCY_ACCESS_APPROVAL_TIMEOUT", emergencyAccessConfig.ApprovalTimeout)
+		emergencyAccessService = impl.NewEmergencyAccessService(
+			postgres.NewEmergencyAccessRepository(db),
+			emergencyAccessConfig,
+		)
+
+		scheduler := impl.NewEmergencyAccessScheduler(
+			emergencyAccessService,
+			getEnvDuration("EMERGENCY_ACCESS_SWEEP_INTERVAL", impl.DefaultEmergencyAccessSweepInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar as campanhas de recertificação de acessos e os seus escalamentos automáticos
+	// Os donos das funções registados nos pedidos de acesso revêem os itens das suas funções
+	var recertificationService application.RecertificationService
+	if getEnv("RECERTIFICATION_ENABLED", "true") == "true" {
+		recertificationConfig := impl.DefaultRecertificationConfig()
+		recertificationConfig.EscalationLead = getEnvDuration("RECERTIFICATION_ESCALATION_LEAD", recertificationConfig.EscalationLead)
+		recertificationService = impl.NewRecertificationService(
+			postgres.NewRecertificationRepository(db),
+			roleService,
+			accessRequestService,
+			eventPublisher,
+			recertificationConfig,
+		)
+
+		scheduler := impl.NewRecertificationScheduler(
+			recertificationService,
+			getEnvDuration("RECERTIFICATION_SWEEP_INTERVAL", impl.DefaultRecertificationSweepInterval),
+		)
+		go scheduler.Start(lifecycleCtx)
+	}
+
+	// Configurar a identidade visual e os modelos de email dos tenants (convites, redefinição de senha e MFA)
+	var tenantBrandingService application.TenantBrandingService
+	if getEnv("TENANT_BRANDING_ENABLED", "true") == "true" {
+		tenantBrandingService = impl.NewTenantBrandingService(postgres.NewTenantBrandingRepository(db))
+	}
+
 	// Configurar servidor HTTP
 	log.Info().Msg("Inicializando servidor HTTP")
 	serverConfig := server.DefaultConfig()
@@ -106,6 +682,120 @@ func main() {
 	}
 	
 	httpServer := server.New(serverConfig, roleService, log.With().Str("component", "Server").Logger())
+	httpServer.SetImpactAnalysisService(impl.NewImpactAnalysisService(roleService))
+	httpServer.SetRoleHistoryService(roleHistoryService)
+	httpServer.SetAccessRequestService(accessRequestService)
+	httpServer.SetRoleTemplateService(roleTemplateService)
+	if samlFederationService != nil {
+		httpServer.SetSAMLFederationService(samlFederationService)
+	}
+	if auditAnomalyService != nil {
+		httpServer.SetAuditAnomalyService(auditAnomalyService)
+	}
+	if tenantExportService != nil {
+		httpServer.SetTenantExportService(tenantExportService)
+	}
+	if permissionDecisionService != nil {
+		httpServer.SetPermissionDecisionAuditService(permissionDecisionService)
+	}
+	if auditLogService != nil {
+		httpServer.SetAuditLogService(auditLogService)
+	}
+	if roleMiningService != nil {
+		httpServer.SetRoleMiningService(roleMiningService)
+	}
+	if passwordlessService != nil {
+		httpServer.SetPasswordlessService(passwordlessService)
+	}
+	// Os proxies de confiança indicam o endereço do cliente em X-Forwarded-For
+	var trustedProxies []string
+	if proxies := getEnv("NETWORK_POLICY_TRUSTED_PROXIES", ""); proxies != "" {
+		trustedProxies = strings.Split(proxies, ",")
+	}
+	httpServer.SetTrustedProxies(trustedProxies)
+	if networkPolicyService != nil {
+		httpServer.SetNetworkPolicyService(networkPolicyService)
+
+		// Aplicar as políticas de rede aos pedidos; os proxies de confiança indicam o cliente em X-Forwarded-For
+		networkPolicyMiddlewareConfig := middleware.DefaultNetworkPolicyConfig()
+		networkPolicyMiddlewareConfig.TrustedProxies = trustedProxies
+		networkPolicyMiddlewareConfig.FailOpen = getEnv("NETWORK_POLICY_FAIL_OPEN", "false") == "true"
+		httpServer.SetNetworkPolicyConfig(networkPolicyMiddlewareConfig)
+	}
+	if sessionPolicyService != nil {
+		httpServer.SetSessionPolicyService(sessionPolicyService)
+
+		// Aplicar as políticas de sessão aos pedidos autenticados
+		sessionPolicyMiddlewareConfig := middleware.DefaultSessionPolicyConfig()
+		sessionPolicyMiddlewareConfig.FailOpen = getEnv("SESSION_POLICY_FAIL_OPEN", "false") == "true"
+		httpServer.SetSessionPolicyConfig(sessionPolicyMiddlewareConfig)
+	}
+	if roleMetadataPolicyService != nil {
+		httpServer.SetRoleMetadataPolicyService(roleMetadataPolicyService)
+	}
+	if emergencyAccessService != nil {
+		httpServer.SetEmergencyAccessService(emergencyAccessService)
+
+		// Selar as contas de emergência fora das ativações aprovadas e gravar as suas ações
+		emergencyAccessMiddlewareConfig := middleware.DefaultEmergencyAccessConfig()
+		emergencyAccessMiddlewareConfig.TrustedProxies = trustedProxies
+		httpServer.SetEmergencyAccessConfig(emergencyAccessMiddlewareConfig)
+	}
+	if accountLinkService != nil {
+		httpServer.SetAccountLinkService(accountLinkService)
+	}
+	if legalConsentService != nil {
+		httpServer.SetLegalConsentService(legalConsentService)
+	}
+	if tokenExchangeService != nil {
+		httpServer.SetTokenExchangeService(tokenExchangeService)
+	}
+	if serviceAccountService != nil {
+		httpServer.SetServiceAccountService(serviceAccountService)
+	}
+	if recertificationService != nil {
+		httpServer.SetRecertificationService(recertificationService)
+	}
+	if userAttributeService != nil {
+		httpServer.SetUserAttributeService(userAttributeService)
+	}
+	if loginNotificationService != nil {
+		httpServer.SetLoginNotificationService(loginNotificationService)
+	}
+	if permissionBoundaryService != nil {
+		httpServer.SetPermissionBoundaryService(permissionBoundaryService)
+	}
+	if bulkUserJobService != nil {
+		httpServer.SetBulkUserJobService(bulkUserJobService)
+	}
+	if tenantBrandingService != nil {
+		httpServer.SetTenantBrandingService(tenantBrandingService)
+	}
+	if deviceService != nil {
+		httpServer.SetDeviceService(deviceService)
+	}
+
+	// Autenticar os pedidos da API; os fluxos de login públicos obtêm o tenant do próprio pedido
+	authConfig := middleware.DefaultAuthConfig()
+	authConfig.SkipPaths = append(authConfig.SkipPaths,
+		"/api/v1/passwordless/start",
+		"/api/v1/passwordless/magic-link/verify",
+		"/api/v1/passwordless/otp/verify",
+		"/api/v1/saml/",
+		"/api/v1/login-notifications/respond",
+	)
+	httpServer.SetAuthConfig(authConfig)
+
+	// Autorizar as operações por políticas OPA quando o PDP estiver configurado
+	if opaEndpoint := getEnv("AUTHZ_OPA_ENDPOINT", ""); opaEndpoint != "" {
+		authzConfig := middleware.DefaultAuthzConfig()
+		authzConfig.OPAEndpoint = opaEndpoint
+		authzConfig.PolicyPath = getEnv("AUTHZ_POLICY_PATH", authzConfig.PolicyPath)
+		authzConfig.DecisionPath = getEnv("AUTHZ_DECISION_PATH", authzConfig.DecisionPath)
+		authzConfig.Timeout = getEnvDuration("AUTHZ_TIMEOUT", authzConfig.Timeout)
+		authzConfig.TrustedProxies = trustedProxies
+		httpServer.SetAuthzConfig(authzConfig)
+	}
 
 	// Iniciar servidor HTTP em uma goroutine
 	go func() {
@@ -122,6 +812,7 @@ func main() {
 	// Esperar por sinal para encerramento
 	sig := <-quit
 	log.Info().Msgf("Recebido sinal %s, iniciando encerramento gracioso", sig)
+	stopLifecycle()
 
 	// Encerrar servidor HTTP
 	ctx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
@@ -132,6 +823,13 @@ func main() {
 		log.Error().Err(err).Msg("Falha ao encerrar o servidor HTTP")
 	}
 
+	// Gravar as decisões de autorização dos últimos pedidos atendidos
+	if permissionDecisionService != nil {
+		if err := permissionDecisionService.Flush(ctx); err != nil {
+			log.Error().Err(err).Msg("Falha ao gravar as decisões de autorização pendentes")
+		}
+	}
+
 	log.Info().Msg("Serviço de identidade do INNOVABIZ IAM encerrado com sucesso")
 }
 
@@ -208,6 +906,27 @@ func setupTelemetry() (*trace.TracerProvider, error) {
 	return tp, nil
 }
 
+// loadTenantExportSigningKey lê a chave Ed25519 (PEM, PKCS #8) que assina os manifestos das exportações
+func loadTenantExportSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler a chave de assinatura: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("a chave de assinatura não está em formato PEM")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao interpretar a chave de assinatura: %w", err)
+	}
+	signingKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("a chave de assinatura deve ser Ed25519")
+	}
+	return signingKey, nil
+}
+
 // Helper functions for environment variables
 
 // getEnv retorna o valor da variável de ambiente ou o valor padrão
@@ -228,6 +947,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat retorna o valor da variável de ambiente como número decimal ou o valor padrão
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvDuration retorna o valor da variável de ambiente como duração ou o valor padrão
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value, exists := os.LookupEnv(key); exists {
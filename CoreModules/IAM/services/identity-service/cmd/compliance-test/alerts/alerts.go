@@ -0,0 +1,242 @@
+// Package alerts deteta os desvios de compliance das execuções agendadas e entrega as
+// notificações aos canais configurados
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+)
+
+// Tipos de alerta de desvio de compliance
+const (
+	KindFrameworkBelowThreshold   = "framework_below_threshold"
+	KindCriticalRequirementFailed = "critical_requirement_failed"
+)
+
+// SignatureHeader é o cabeçalho com a assinatura dos alertas enviados por webhook:
+// t=<unix>,v1=<hex(hmac_sha256(segredo, t + "." + corpo))>
+const SignatureHeader = "X-Compliance-Signature"
+
+// Thresholds define a pontuação mínima de cada framework
+type Thresholds struct {
+	padrao     float64
+	frameworks map[string]float64
+}
+
+// ParseThresholds interpreta os limites por framework no formato "LGPD=90,GDPR=95"
+func ParseThresholds(padrao float64, valor string) (Thresholds, error) {
+	limites := Thresholds{padrao: padrao, frameworks: make(map[string]float64)}
+	if padrao < 0 || padrao > 100 {
+		return limites, fmt.Errorf("limite de pontuação inválido: %v", padrao)
+	}
+	for _, parte := range strings.Split(valor, ",") {
+		parte = strings.TrimSpace(parte)
+		if parte == "" {
+			continue
+		}
+		id, limiteStr, ok := strings.Cut(parte, "=")
+		limite, err := strconv.ParseFloat(strings.TrimSpace(limiteStr), 64)
+		if !ok || err != nil || limite < 0 || limite > 100 {
+			return limites, fmt.Errorf("limite de framework inválido: %q (formato: FRAMEWORK=0-100)", parte)
+		}
+		limites.frameworks[strings.ToUpper(strings.TrimSpace(id))] = limite
+	}
+	return limites, nil
+}
+
+// For retorna o limite do framework
+func (l Thresholds) For(frameworkID string) float64 {
+	if limite, ok := l.frameworks[strings.ToUpper(frameworkID)]; ok {
+		return limite
+	}
+	return l.padrao
+}
+
+// Default retorna o limite dos frameworks sem limite próprio
+func (l Thresholds) Default() float64 {
+	return l.padrao
+}
+
+// Alert descreve um desvio de compliance detetado numa execução agendada
+type Alert struct {
+	RunID         string    `json:"runId"`
+	Region        string    `json:"region"`
+	RegionName    string    `json:"regionName,omitempty"`
+	Kind          string    `json:"kind"`
+	FrameworkID   string    `json:"frameworkId,omitempty"`
+	FrameworkName string    `json:"frameworkName,omitempty"`
+	RequirementID string    `json:"requirementId,omitempty"`
+	Score         *float64  `json:"score,omitempty"`
+	PreviousScore *float64  `json:"previousScore,omitempty"`
+	Threshold     *float64  `json:"threshold,omitempty"`
+	Message       string    `json:"message"`
+	DetectedAt    time.Time `json:"detectedAt"`
+}
+
+// Evaluate compara a execução com a anterior da mesma região
+// Um framework gera alerta quando a pontuação desce abaixo do limite (e não quando já estava abaixo),
+// e um requisito crítico quando falha nesta execução e não falhava na anterior;
+// sem execução anterior, todas as falhas são consideradas novas
+func Evaluate(atual history.Run, anterior *history.Run, limites Thresholds) []Alert {
+	var alertas []Alert
+
+	ids := make([]string, 0, len(atual.FrameworkScores))
+	for id := range atual.FrameworkScores {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		score := atual.FrameworkScores[id]
+		limite := limites.For(id)
+		if score.TotalTests == 0 || score.ComplianceScore >= limite {
+			continue
+		}
+
+		alerta := Alert{
+			RunID:         atual.RunID,
+			Region:        atual.Region,
+			RegionName:    atual.RegionName,
+			Kind:          KindFrameworkBelowThreshold,
+			FrameworkID:   id,
+			FrameworkName: score.Name,
+			Score:         &score.ComplianceScore,
+			Threshold:     &limite,
+			DetectedAt:    atual.ExecutedAt,
+		}
+		if anterior != nil {
+			if prev, ok := anterior.FrameworkScores[id]; ok && prev.TotalTests > 0 {
+				if prev.ComplianceScore < limite {
+					continue
+				}
+				alerta.PreviousScore = &prev.ComplianceScore
+			}
+		}
+		alerta.Message = fmt.Sprintf("Pontuação do framework %s na região %s desceu para %.1f%% (limite %.1f%%)",
+			id, atual.Region, score.ComplianceScore, limite)
+		alertas = append(alertas, alerta)
+	}
+
+	for _, reqID := range atual.CriticalRequirementsFailed {
+		if anterior != nil && contains(anterior.RequirementsFailed, reqID) {
+			continue
+		}
+		alertas = append(alertas, Alert{
+			RunID:         atual.RunID,
+			Region:        atual.Region,
+			RegionName:    atual.RegionName,
+			Kind:          KindCriticalRequirementFailed,
+			RequirementID: reqID,
+			Message:       fmt.Sprintf("Requisito crítico %s passou a falhar na região %s", reqID, atual.Region),
+			DetectedAt:    atual.ExecutedAt,
+		})
+	}
+	return alertas
+}
+
+// Notification é o corpo enviado aos canais de alerta para uma região
+type Notification struct {
+	Region string         `json:"region"`
+	RunID  string         `json:"runId"`
+	Alerts []Alert        `json:"alerts"`
+	Trend  *history.Trend `json:"trend,omitempty"`
+	SentAt time.Time      `json:"sentAt"`
+}
+
+// Channel entrega as notificações de desvio de compliance
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, notificacao Notification) error
+}
+
+// Webhook envia as notificações em JSON, assinadas com HMAC-SHA256 quando há segredo
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook cria o canal de webhook; sem segredo os alertas não são assinados
+func NewWebhook(url, secret string, client *http.Client) *Webhook {
+	return &Webhook{url: url, secret: secret, client: client}
+}
+
+// Name retorna o nome do canal registado com o alerta
+func (c *Webhook) Name() string { return "webhook" }
+
+// Send envia a notificação em JSON e falha quando o destino responde com um erro HTTP
+func (c *Webhook) Send(ctx context.Context, notificacao Notification) error {
+	body, err := json.Marshal(notificacao)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar alerta: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("erro ao criar pedido do webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.secret, notificacao.SentAt, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook respondeu %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign calcula o cabeçalho de assinatura do corpo do webhook
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Deliver entrega a notificação em todos os canais e retorna os canais que a receberam
+func Deliver(ctx context.Context, canais []Channel, notificacao Notification) ([]string, error) {
+	var entregues []string
+	var errs []error
+	for _, canal := range canais {
+		if err := canal.Send(ctx, notificacao); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", canal.Name(), err))
+			continue
+		}
+		entregues = append(entregues, canal.Name())
+	}
+	return entregues, errors.Join(errs...)
+}
+
+// contains verifica se um slice contém um determinado item
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
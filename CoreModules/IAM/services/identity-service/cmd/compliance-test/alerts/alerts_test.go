@@ -0,0 +1,241 @@
+package alerts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThresholds(t *testing.T) {
+	limites, err := ParseThresholds(90, " LGPD=95 , gdpr=80.5,,")
+	require.NoError(t, err)
+	assert.Equal(t, 90.0, limites.Default())
+	assert.Equal(t, 95.0, limites.For("lgpd"))
+	assert.Equal(t, 80.5, limites.For("GDPR"))
+	assert.Equal(t, 90.0, limites.For("BNA"), "frameworks sem limite próprio usam o limite padrão")
+
+	limites, err = ParseThresholds(75, "")
+	require.NoError(t, err)
+	assert.Equal(t, 75.0, limites.For("LGPD"))
+
+	invalidos := []struct {
+		padrao float64
+		valor  string
+	}{
+		{101, ""},
+		{-1, ""},
+		{90, "LGPD"},
+		{90, "LGPD=noventa"},
+		{90, "LGPD=100.1"},
+		{90, "LGPD=-5"},
+	}
+	for _, tt := range invalidos {
+		_, err := ParseThresholds(tt.padrao, tt.valor)
+		assert.Error(t, err, "%v %q", tt.padrao, tt.valor)
+	}
+}
+
+// execucaoAngola cria uma execução de Angola com as pontuações por framework e os requisitos reprovados
+func execucaoAngola(pontuacoes map[string]float64, reprovados, criticos []string) history.Run {
+	frameworks := make(map[string]report.FrameworkScore, len(pontuacoes))
+	for id, score := range pontuacoes {
+		frameworks[id] = report.FrameworkScore{ID: id, Name: "Framework " + id, TotalTests: 10, ComplianceScore: score}
+	}
+	return history.Run{
+		RunID:                      "run-2",
+		Region:                     "AO",
+		RegionName:                 "Angola",
+		FrameworkScores:            frameworks,
+		RequirementsFailed:         reprovados,
+		CriticalRequirementsFailed: criticos,
+		ExecutedAt:                 time.Date(2026, 4, 2, 6, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestEvaluateFrameworkThresholds(t *testing.T) {
+	limites, err := ParseThresholds(90, "FATF=70")
+	require.NoError(t, err)
+
+	tests := []struct {
+		nome      string
+		atual     map[string]float64
+		anterior  map[string]float64
+		semAntes  bool
+		alertas   []string
+		anteriors []*float64
+	}{
+		{"acima do limite", map[string]float64{"BNA": 95}, map[string]float64{"BNA": 95}, false, nil, nil},
+		{"igual ao limite", map[string]float64{"BNA": 90}, map[string]float64{"BNA": 95}, false, nil, nil},
+		{"desce abaixo do limite", map[string]float64{"BNA": 85}, map[string]float64{"BNA": 95}, false, []string{"BNA"}, []*float64{ptr(95)}},
+		{"já estava abaixo do limite", map[string]float64{"BNA": 80}, map[string]float64{"BNA": 85}, false, nil, nil},
+		{"sem execução anterior", map[string]float64{"BNA": 85}, nil, true, []string{"BNA"}, []*float64{nil}},
+		{"framework novo", map[string]float64{"BNA": 85}, map[string]float64{}, false, []string{"BNA"}, []*float64{nil}},
+		{"limite por framework", map[string]float64{"FATF": 75, "LGPD": 75}, map[string]float64{"FATF": 100, "LGPD": 100}, false, []string{"LGPD"}, []*float64{ptr(100)}},
+		{"ordenados por framework", map[string]float64{"LGPD": 10, "BNA": 10}, nil, true, []string{"BNA", "LGPD"}, []*float64{nil, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			var anterior *history.Run
+			if !tt.semAntes {
+				run := execucaoAngola(tt.anterior, nil, nil)
+				anterior = &run
+			}
+
+			alertas := Evaluate(execucaoAngola(tt.atual, nil, nil), anterior, limites)
+			require.Len(t, alertas, len(tt.alertas))
+			for i, alerta := range alertas {
+				assert.Equal(t, KindFrameworkBelowThreshold, alerta.Kind)
+				assert.Equal(t, tt.alertas[i], alerta.FrameworkID)
+				assert.Equal(t, "Framework "+tt.alertas[i], alerta.FrameworkName)
+				assert.Equal(t, tt.atual[tt.alertas[i]], *alerta.Score)
+				assert.Equal(t, limites.For(tt.alertas[i]), *alerta.Threshold)
+				assert.Equal(t, tt.anteriors[i], alerta.PreviousScore)
+				assert.Equal(t, "run-2", alerta.RunID)
+				assert.Equal(t, "AO", alerta.Region)
+			}
+		})
+	}
+}
+
+func TestEvaluateFrameworkWithoutTests(t *testing.T) {
+	limites, err := ParseThresholds(90, "")
+	require.NoError(t, err)
+
+	atual := execucaoAngola(nil, nil, nil)
+	atual.FrameworkScores = map[string]report.FrameworkScore{"BNA": {TotalTests: 0, ComplianceScore: 0}}
+	assert.Empty(t, Evaluate(atual, nil, limites))
+
+	// Uma pontuação anterior sem testes não conta como estar acima do limite
+	anterior := execucaoAngola(nil, nil, nil)
+	anterior.FrameworkScores = map[string]report.FrameworkScore{"BNA": {TotalTests: 0}}
+	alertas := Evaluate(execucaoAngola(map[string]float64{"BNA": 50}, nil, nil), &anterior, limites)
+	require.Len(t, alertas, 1)
+	assert.Nil(t, alertas[0].PreviousScore)
+	assert.Equal(t, "Pontuação do framework BNA na região AO desceu para 50.0% (limite 90.0%)", alertas[0].Message)
+}
+
+func TestEvaluateCriticalRequirements(t *testing.T) {
+	limites, err := ParseThresholds(90, "")
+	require.NoError(t, err)
+
+	atual := execucaoAngola(map[string]float64{"BNA": 100}, []string{"ao-aml-req-01", "ao-aml-req-02"}, []string{"ao-aml-req-01", "ao-aml-req-02"})
+	anterior := execucaoAngola(map[string]float64{"BNA": 100}, []string{"ao-aml-req-02"}, []string{"ao-aml-req-02"})
+
+	alertas := Evaluate(atual, &anterior, limites)
+	require.Len(t, alertas, 1, "apenas requisitos que passaram a falhar geram alerta")
+	assert.Equal(t, KindCriticalRequirementFailed, alertas[0].Kind)
+	assert.Equal(t, "ao-aml-req-01", alertas[0].RequirementID)
+	assert.Equal(t, "Requisito crítico ao-aml-req-01 passou a falhar na região AO", alertas[0].Message)
+	assert.Equal(t, atual.ExecutedAt, alertas[0].DetectedAt)
+	assert.Nil(t, alertas[0].Score)
+
+	// Sem execução anterior, todas as falhas críticas são novas e seguem os alertas dos frameworks
+	atual.FrameworkScores["BNA"] = report.FrameworkScore{TotalTests: 10, ComplianceScore: 60}
+	alertas = Evaluate(atual, nil, limites)
+	require.Len(t, alertas, 3)
+	assert.Equal(t, KindFrameworkBelowThreshold, alertas[0].Kind)
+	assert.Equal(t, "ao-aml-req-01", alertas[1].RequirementID)
+	assert.Equal(t, "ao-aml-req-02", alertas[2].RequirementID)
+}
+
+func TestWebhookSend(t *testing.T) {
+	var corpo []byte
+	var assinatura, contentType string
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		corpo, _ = io.ReadAll(r.Body)
+		assinatura = r.Header.Get(SignatureHeader)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	enviadaEm := time.Date(2026, 4, 2, 6, 5, 0, 0, time.UTC)
+	notificacao := Notification{
+		Region: "AO",
+		RunID:  "run-2",
+		Alerts: []Alert{{Region: "AO", Kind: KindCriticalRequirementFailed, RequirementID: "ao-aml-req-01", Message: "falhou"}},
+		Trend:  &history.Trend{Region: "AO", Runs: 2, Score: 80},
+		SentAt: enviadaEm,
+	}
+
+	webhook := NewWebhook(server.URL, "segredo", server.Client())
+	assert.Equal(t, "webhook", webhook.Name())
+	require.NoError(t, webhook.Send(context.Background(), notificacao))
+
+	assert.Equal(t, "application/json", contentType)
+	var recebida Notification
+	require.NoError(t, json.Unmarshal(corpo, &recebida))
+	assert.Equal(t, "ao-aml-req-01", recebida.Alerts[0].RequirementID)
+	assert.Equal(t, 80.0, recebida.Trend.Score)
+
+	// O destinatário valida a assinatura com o segredo partilhado
+	mac := hmac.New(sha256.New, []byte("segredo"))
+	mac.Write([]byte("1775109900." + string(corpo)))
+	assert.Equal(t, "t=1775109900,v1="+hex.EncodeToString(mac.Sum(nil)), assinatura)
+	assert.Equal(t, Sign("segredo", enviadaEm, corpo), assinatura)
+	assert.NotEqual(t, Sign("outro", enviadaEm, corpo), assinatura)
+
+	// Sem segredo os alertas não são assinados
+	require.NoError(t, NewWebhook(server.URL, "", server.Client()).Send(context.Background(), notificacao))
+	assert.Empty(t, assinatura)
+
+	// Respostas de erro do destino falham a entrega
+	status = http.StatusBadGateway
+	err := webhook.Send(context.Background(), notificacao)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook respondeu 502")
+}
+
+// canalFalso regista as notificações recebidas e falha quando configurado com erro
+type canalFalso struct {
+	nome     string
+	erro     error
+	recebida []Notification
+}
+
+func (c *canalFalso) Name() string { return c.nome }
+
+func (c *canalFalso) Send(ctx context.Context, notificacao Notification) error {
+	c.recebida = append(c.recebida, notificacao)
+	return c.erro
+}
+
+func TestDeliver(t *testing.T) {
+	webhook := &canalFalso{nome: "webhook"}
+	email := &canalFalso{nome: "email", erro: errors.New("SMTP indisponível")}
+	outro := &canalFalso{nome: "slack"}
+	notificacao := Notification{Region: "AO", RunID: "run-2"}
+
+	entregues, err := Deliver(context.Background(), []Channel{webhook, email, outro}, notificacao)
+
+	// Um canal com falha não impede a entrega nos restantes
+	assert.Equal(t, []string{"webhook", "slack"}, entregues)
+	require.Error(t, err)
+	assert.EqualError(t, err, "email: SMTP indisponível")
+	for _, canal := range []*canalFalso{webhook, email, outro} {
+		assert.Equal(t, []Notification{notificacao}, canal.recebida, canal.nome)
+	}
+
+	entregues, err = Deliver(context.Background(), nil, notificacao)
+	assert.NoError(t, err)
+	assert.Empty(t, entregues)
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/pdp"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/policybundle"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/rego"
+	"go.uber.org/zap"
+)
+
+// Nome lógico do bundle carregado para avaliação dos testes
+const complianceBundleName = "compliance"
+
+// ambientePoliticas reúne os artefatos OPA usados na avaliação dos casos de teste
+type ambientePoliticas struct {
+	opaPath   string
+	bundle    *bundle.Bundle
+	dataPaths []string
+	decisoes  *decisionlog.Writer
+	pdp       *pdp.Client // Com --target pdp, as decisões são pedidas ao PDP em execução
+}
+
+// carregarAmbientePoliticas prepara as políticas a partir de um bundle OPA ou do diretório de políticas
+func carregarAmbientePoliticas(logger *zap.Logger, config Config) (*ambientePoliticas, error) {
+	env := &ambientePoliticas{
+		opaPath:   config.OPAPath,
+		dataPaths: config.DataPaths,
+	}
+
+	// Documentos de dados e políticas auxiliares partilhadas devem existir antes da execução
+	for _, p := range config.DataPaths {
+		if _, err := os.Stat(p); err != nil {
+			return nil, fmt.Errorf("caminho de dados ou políticas auxiliares inválido %s: %w", p, err)
+		}
+	}
+
+	// No PDP, o bundle indicado em --bundle serve apenas de referência para a revisão esperada
+	if config.Target == alvoPDP {
+		cliente, err := pdp.New(config.PDPURL, config.PDPToken, config.PDPTimeout)
+		if err != nil {
+			return nil, err
+		}
+		env.pdp = cliente
+		logger.Info("Casos de teste avaliados no PDP", zap.String("pdp_url", config.PDPURL))
+	}
+
+	if config.BundlePath == "" {
+		return env, nil
+	}
+
+	b, err := carregarBundle(config)
+	if err != nil {
+		return nil, err
+	}
+	env.bundle = b
+
+	roots := []string{}
+	if b.Manifest.Roots != nil {
+		roots = *b.Manifest.Roots
+	}
+
+	if config.BundleSkipVerify {
+		logger.Warn("Assinatura do bundle OPA não verificada (--bundle-skip-verify)",
+			zap.String("path", config.BundlePath))
+	}
+
+	// carregarBundle só retorna sem erro com a assinatura verificada ou com
+	// --bundle-skip-verify explícito
+	logger.Info("Bundle OPA carregado",
+		zap.String("path", config.BundlePath),
+		zap.String("revision", b.Manifest.Revision),
+		zap.Strings("roots", roots),
+		zap.Int("modules", len(b.Modules)),
+		zap.Bool("signed", len(b.Signatures.Signatures) > 0),
+		zap.Bool("verified", !config.BundleSkipVerify),
+		zap.Bool("skip_verify", config.BundleSkipVerify))
+
+	return env, nil
+}
+
+// carregarBundle lê o bundle OPA indicado em --bundle e verifica a sua assinatura.
+// Sem --bundle-verify-key, o bundle só é aceite com --bundle-skip-verify explícito
+func carregarBundle(config Config) (*bundle.Bundle, error) {
+	return policybundle.Load(policybundle.Options{
+		Path:        config.BundlePath,
+		VerifyKey:   config.BundleVerifyKey,
+		VerifyKeyID: config.BundleVerifyKeyID,
+		VerifyAlg:   config.BundleVerifyAlg,
+		VerifyScope: config.BundleVerifyScope,
+		SkipVerify:  config.BundleSkipVerify,
+	})
+}
+
+// opcoesRego retorna as opções de carga de políticas e dados para um caso de teste
+func (env *ambientePoliticas) opcoesRego(testCase TestCase) []func(*rego.Rego) {
+	var options []func(*rego.Rego)
+	var paths []string
+
+	if env.bundle != nil {
+		// O bundle contém as políticas, as bibliotecas auxiliares e os documentos data.json
+		options = append(options, rego.ParsedBundle(complianceBundleName, env.bundle))
+	} else {
+		paths = append(paths, filepath.Join(env.opaPath, testCase.PolicyPath))
+	}
+
+	paths = append(paths, env.dataPaths...)
+	for _, dataFile := range testCase.DataFiles {
+		paths = append(paths, filepath.Join(env.opaPath, dataFile))
+	}
+
+	if len(paths) > 0 {
+		options = append(options, rego.Load(paths, nil))
+	}
+
+	return options
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+)
+
+// registarDecisao acrescenta a decisão avaliada num caso de teste ao log de decisões, quando ativo
+func (env *ambientePoliticas) registarDecisao(region string, result *TestResult) error {
+	if env.decisoes == nil {
+		return nil
+	}
+
+	var revisao string
+	if env.pdp != nil {
+		revisao = env.pdp.Revision()
+	} else if env.bundle != nil {
+		revisao = env.bundle.Manifest.Revision
+	}
+
+	decisao, err := decisionlog.NewDecision(region, result, revisao)
+	if err != nil {
+		return err
+	}
+	return env.decisoes.Write(decisao)
+}
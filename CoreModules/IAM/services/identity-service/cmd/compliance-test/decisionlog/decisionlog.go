@@ -0,0 +1,161 @@
+// Package decisionlog grava e lê o log de decisões OPA das execuções de compliance em NDJSON
+package decisionlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+)
+
+// Decision é uma linha NDJSON do log de decisões OPA, com os campos do decision log do OPA
+// (decision_id, path, input, result, metrics) e a identificação do caso de teste que a produziu
+type Decision struct {
+	DecisionID     string                 `json:"decision_id"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Region         string                 `json:"region"`
+	TestID         string                 `json:"test_id"`
+	Path           string                 `json:"path"`
+	Query          string                 `json:"query"`
+	DataFiles      []string               `json:"data_files,omitempty"`
+	BundleRevision string                 `json:"bundle_revision,omitempty"`
+	Input          interface{}            `json:"input"`
+	Result         interface{}            `json:"result"`
+	Error          string                 `json:"error,omitempty"`
+	Expected       interface{}            `json:"expected,omitempty"`
+	Passed         bool                   `json:"passed"`
+	Metrics        map[string]interface{} `json:"metrics,omitempty"`
+}
+
+// NewDecision cria o registo da decisão avaliada num caso de teste, com a revisão das políticas avaliadas
+func NewDecision(region string, result *report.TestResult, revision string) (Decision, error) {
+	// No PDP, o ID é o da decisão registada pelo próprio PDP, para correlação com o seu decision log
+	id := result.PDPDecisionID
+	if id == "" {
+		var err error
+		if id, err = novoID(); err != nil {
+			return Decision{}, err
+		}
+	}
+
+	decisao := Decision{
+		DecisionID:     id,
+		Timestamp:      result.ExecutedAt.UTC(),
+		Region:         region,
+		TestID:         result.TestCase.ID,
+		Path:           result.PolicyPath,
+		Query:          Query(result.PolicyPath),
+		DataFiles:      result.TestCase.DataFiles,
+		BundleRevision: revision,
+		Input:          result.TestCase.Input,
+		Result:         result.ActualDecision,
+		Expected:       result.TestCase.ExpectedDecision,
+		Passed:         result.Passed,
+		Metrics:        result.Metrics,
+	}
+	if result.EvaluationError != nil {
+		decisao.Error = result.EvaluationError.Error()
+	}
+	return decisao, nil
+}
+
+// Writer escreve o log de decisões OPA de uma execução em NDJSON
+// Um Writer nulo descarta as decisões, para quando a captura está desativada
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// Create cria (ou substitui) o arquivo do log de decisões
+// Um caminho vazio desativa a captura e retorna um Writer nulo
+func Create(path string) (*Writer, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar log de decisões: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	return &Writer{file: file, writer: writer, encoder: encoder}, nil
+}
+
+// Write acrescenta uma decisão ao log
+func (w *Writer) Write(decisao Decision) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(decisao)
+}
+
+// Close grava as decisões pendentes e fecha o arquivo
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("erro ao gravar log de decisões: %w", err)
+	}
+	return w.file.Close()
+}
+
+// Read percorre as decisões de um log NDJSON pela ordem em que foram registadas
+func Read(reader io.Reader, fn func(decisao *Decision) error) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	for linha := 1; ; linha++ {
+		var decisao Decision
+		if err := decoder.Decode(&decisao); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decisão %d inválida no log: %w", linha, err)
+		}
+		if decisao.Path == "" {
+			return fmt.Errorf("decisão %d sem caminho de política", linha)
+		}
+		if decisao.Query == "" {
+			decisao.Query = Query(decisao.Path)
+		}
+		if err := fn(&decisao); err != nil {
+			return err
+		}
+	}
+}
+
+// Query retorna a consulta Rego da decisão de uma política
+func Query(policyPath string) string {
+	return "data." + strings.ReplaceAll(policyPath, "/", ".")
+}
+
+// novoID gera um identificador aleatório para a decisão
+func novoID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("erro ao gerar ID da decisão: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
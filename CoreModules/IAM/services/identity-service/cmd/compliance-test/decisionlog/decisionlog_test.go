@@ -0,0 +1,147 @@
+package decisionlog
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultadoAML cria o resultado de um caso de teste AML avaliado localmente
+func resultadoAML() *report.TestResult {
+	return &report.TestResult{
+		TestCase: report.TestCase{
+			ID:               "ao-aml-001",
+			PolicyPath:       "aml/angola/pep",
+			Input:            map[string]interface{}{"pep": true},
+			ExpectedDecision: map[string]interface{}{"allow": false},
+			DataFiles:        []string{"data/pep.json"},
+		},
+		ActualDecision: map[string]interface{}{"allow": true},
+		PolicyPath:     "aml/angola/pep",
+		ExecutedAt:     time.Date(2026, 4, 2, 7, 0, 0, 0, time.FixedZone("WAT", 3600)),
+		Metrics:        map[string]interface{}{"timer_rego_query_eval_ns": 1200},
+	}
+}
+
+func TestNewDecision(t *testing.T) {
+	decisao, err := NewDecision("AO", resultadoAML(), "v42")
+	require.NoError(t, err)
+
+	assert.Len(t, decisao.DecisionID, 32, "ID aleatório em hexadecimal")
+	assert.Equal(t, time.Date(2026, 4, 2, 6, 0, 0, 0, time.UTC), decisao.Timestamp)
+	assert.Equal(t, "AO", decisao.Region)
+	assert.Equal(t, "ao-aml-001", decisao.TestID)
+	assert.Equal(t, "aml/angola/pep", decisao.Path)
+	assert.Equal(t, "data.aml.angola.pep", decisao.Query)
+	assert.Equal(t, []string{"data/pep.json"}, decisao.DataFiles)
+	assert.Equal(t, "v42", decisao.BundleRevision)
+	assert.Equal(t, map[string]interface{}{"allow": true}, decisao.Result)
+	assert.Equal(t, map[string]interface{}{"allow": false}, decisao.Expected)
+	assert.False(t, decisao.Passed)
+	assert.Empty(t, decisao.Error)
+
+	outra, err := NewDecision("AO", resultadoAML(), "v42")
+	require.NoError(t, err)
+	assert.NotEqual(t, decisao.DecisionID, outra.DecisionID)
+}
+
+func TestNewDecisionFromPDP(t *testing.T) {
+	result := resultadoAML()
+	result.PDPDecisionID = "pdp-7f3a"
+	result.EvaluationError = errors.New("rego_type_error: undefined ref")
+
+	decisao, err := NewDecision("AO", result, "")
+	require.NoError(t, err)
+	assert.Equal(t, "pdp-7f3a", decisao.DecisionID, "o ID do PDP permite correlacionar com o seu decision log")
+	assert.Equal(t, "rego_type_error: undefined ref", decisao.Error)
+	assert.Empty(t, decisao.BundleRevision)
+}
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisoes.ndjson")
+	w, err := Create(path)
+	require.NoError(t, err)
+
+	primeira, err := NewDecision("AO", resultadoAML(), "v42")
+	require.NoError(t, err)
+	segunda := primeira
+	segunda.DecisionID = "d2"
+	segunda.TestID = "ao-aml-002"
+	segunda.Input = map[string]interface{}{"nota": "<script>"}
+	require.NoError(t, w.Write(primeira))
+	require.NoError(t, w.Write(segunda))
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"), "uma decisão por linha")
+	assert.Contains(t, string(data), `"nota":"<script>"`, "o HTML não é escapado")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lidas []*Decision
+	require.NoError(t, Read(file, func(decisao *Decision) error {
+		lidas = append(lidas, decisao)
+		return nil
+	}))
+	require.Len(t, lidas, 2)
+	assert.Equal(t, primeira.DecisionID, lidas[0].DecisionID)
+	assert.Equal(t, "ao-aml-002", lidas[1].TestID)
+	assert.Equal(t, primeira.Timestamp, lidas[0].Timestamp)
+	assert.Equal(t, json.Number("1200"), lidas[0].Metrics["timer_rego_query_eval_ns"], "números lidos sem perda de precisão")
+}
+
+func TestNilWriter(t *testing.T) {
+	w, err := Create("")
+	require.NoError(t, err)
+	assert.Nil(t, w, "um caminho vazio desativa a captura")
+	assert.NoError(t, w.Write(Decision{}))
+	assert.NoError(t, w.Close())
+
+	_, err = Create(filepath.Join(t.TempDir(), "inexistente", "decisoes.ndjson"))
+	assert.Error(t, err)
+}
+
+func TestRead(t *testing.T) {
+	log := `{"decision_id":"d1","path":"aml/angola/pep"}
+{"decision_id":"d2","path":"lgpd/consent","query":"data.lgpd.consent.allow"}
+`
+	var queries []string
+	require.NoError(t, Read(strings.NewReader(log), func(decisao *Decision) error {
+		queries = append(queries, decisao.Query)
+		return nil
+	}))
+	assert.Equal(t, []string{"data.aml.angola.pep", "data.lgpd.consent.allow"}, queries, "sem consulta registada, é derivada do caminho")
+
+	invalidos := []struct {
+		log  string
+		erro string
+	}{
+		{`{"decision_id":"d1","path":"a"}` + "\n{", "decisão 2 inválida no log"},
+		{`{"decision_id":"d1"}`, "decisão 1 sem caminho de política"},
+	}
+	for _, tt := range invalidos {
+		err := Read(strings.NewReader(tt.log), func(*Decision) error { return nil })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.erro)
+	}
+
+	// Um erro da função interrompe a leitura
+	lidas := 0
+	err := Read(strings.NewReader(log), func(*Decision) error {
+		lidas++
+		return errors.New("parar")
+	})
+	assert.EqualError(t, err, "parar")
+	assert.Equal(t, 1, lidas)
+}
@@ -12,7 +12,8 @@ import (
 )
 
 // executarTestesRegionais executa os testes de compliance para uma região específica
-func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
+// e retorna o sumário da execução, ou nil quando a matriz ou os casos de teste não puderam ser carregados
+func executarTestesRegionais(logger *zap.Logger, config Config, env *ambientePoliticas, seletor *seletorTestes, region string) *TestSummary {
 	// Caminho da matriz de conformidade regional
 	matrixPath := filepath.Join(config.TestsDir, "regions", region, "compliance_matrix.json")
 	
@@ -21,7 +22,7 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		logger.Error("Matriz de conformidade não encontrada", 
 			zap.String("region", region),
 			zap.String("path", matrixPath))
-		return
+		return nil
 	}
 
 	// Carrega a matriz de conformidade
@@ -30,7 +31,7 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		logger.Error("Erro ao carregar matriz de conformidade", 
 			zap.String("region", region),
 			zap.Error(err))
-		return
+		return nil
 	}
 
 	logger.Info("Matriz de conformidade carregada com sucesso",
@@ -64,6 +65,10 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		RegionName:      matrix.RegionName,
 		FrameworkScores: make(map[string]FrameworkScore),
 		ExecutedAt:      time.Now(),
+		Target:          config.Target,
+	}
+	if env.pdp != nil {
+		summary.PDPURL = config.PDPURL
 	}
 
 	// Cria mapeamento de requisitos para frameworks
@@ -89,14 +94,15 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		}
 	}
 
-	// Carrega todos os casos de teste para a região
-	testCases, err := carregarCasosTeste(config.TestsDir, region, config.Tags, config.Frameworks)
+	// Carrega os casos de teste da região e aplica a seleção
+	testCases, err := carregarCasosTeste(config.TestsDir, region)
 	if err != nil {
 		logger.Error("Erro ao carregar casos de teste", 
 			zap.String("region", region),
 			zap.Error(err))
-		return
+		return nil
 	}
+	testCases = seletor.selecionar(testCases, reqToFramework, reqToCriticality)
 
 	logger.Info("Casos de teste carregados",
 		zap.String("region", region),
@@ -111,7 +117,7 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 	// Executa os testes
 	for _, testCase := range testCases {
 		// Executa o caso de teste
-		result, err := executarTeste(logger, config.OPAPath, testCase, reqToFramework, reqToCriticality)
+		result, err := executarTeste(logger, env, testCase, reqToFramework, reqToCriticality)
 		if err != nil {
 			logger.Error("Erro ao executar teste",
 				zap.String("testId", testCase.ID),
@@ -119,6 +125,13 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 			continue
 		}
 		
+		// Regista a decisão no log de decisões OPA, quando ativo
+		if err := env.registarDecisao(region, result); err != nil {
+			logger.Warn("Erro ao registar decisão OPA",
+				zap.String("testId", testCase.ID),
+				zap.Error(err))
+		}
+		
 		// Adiciona resultado ao sumário
 		summary.TestResults = append(summary.TestResults, result)
 		
@@ -164,8 +177,10 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 	// Calcula duração total
 	summary.Duration = time.Since(startTime).Milliseconds()
 	
-	// Gera relatórios
-	gerarRelatorios(logger, config, summary)
+	// Revisões dos bundles que produziram as decisões no PDP
+	if env.pdp != nil {
+		summary.PDPRevisions = env.pdp.BundleRevisions()
+	}
 	
 	// Aplicar remediação se habilitada e se houver falhas nos testes
 	if config.Remediate && summary.FailedTests > 0 {
@@ -232,13 +247,9 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		exibirSumarioConsole(summary)
 	}
 
-	// Gerar relatórios
-	if config.Json {
-		gerarRelatorioJSON(summary, config.OutputDir)
-	}
-
-	if config.HTML {
-		gerarRelatorioHTML(summary, config.OutputDir)
+	// Gera relatórios após a remediação, para incluir o seu resultado
+	if config.Json || config.HTML {
+		gerarRelatorios(logger, config, matrix, summary)
 	}
 
 	// Registra estatísticas de teste
@@ -249,6 +260,8 @@ func executarTestesRegionais(logger *zap.Logger, config Config, region string) {
 		zap.Int("failed", summary.FailedTests),
 		zap.Float64("compliance_score", summary.ComplianceScore),
 		zap.Duration("duration", time.Duration(summary.Duration)*time.Millisecond))
+	
+	return summary
 }
 
 // carregarMatrizConformidade carrega a matriz de conformidade de um arquivo JSON
@@ -266,8 +279,29 @@ func carregarMatrizConformidade(filePath string) (*ComplianceMatrix, error) {
 	return &matrix, nil
 }
 
-// carregarCasosTeste carrega os casos de teste para uma região específica
-func carregarCasosTeste(baseDir, region string, tags, frameworks []string) ([]TestCase, error) {
+// selecionarCasosRegiao carrega a matriz e os casos de teste de uma região e aplica a seleção
+func selecionarCasosRegiao(config Config, seletor *seletorTestes, region string) (*ComplianceMatrix, []TestCase, error) {
+	matrix, err := carregarMatrizConformidade(filepath.Join(config.TestsDir, "regions", region, "compliance_matrix.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	reqToFramework := make(map[string]string)
+	reqToCriticality := make(map[string]string)
+	for _, req := range matrix.Requirements {
+		reqToFramework[req.ID] = req.FrameworkID
+		reqToCriticality[req.ID] = req.Criticality
+	}
+	
+	testCases, err := carregarCasosTeste(config.TestsDir, region)
+	if err != nil {
+		return nil, nil, err
+	}
+	return matrix, seletor.selecionar(testCases, reqToFramework, reqToCriticality), nil
+}
+
+// carregarCasosTeste carrega todos os casos de teste de uma região específica
+func carregarCasosTeste(baseDir, region string) ([]TestCase, error) {
 	// Diretório de casos de teste para a região
 	testCasesDir := filepath.Join(baseDir, "regions", region, "test_cases")
 	
@@ -301,15 +335,8 @@ func carregarCasosTeste(baseDir, region string, tags, frameworks []string) ([]Te
 				if err := json.Unmarshal(data, &testCase); err != nil {
 					return nil, fmt.Errorf("erro ao decodificar caso de teste %s: %w", file, err)
 				}
-				
-				// Filtra por tags se especificado
-				if len(tags) > 0 && !anyTagMatch(testCase.Tags, tags) {
-					continue
-				}
-				
-				// Filtra por framework se especificado
-				// Nota: isso requer um processamento adicional após mapear os requisitos
-				// aos frameworks, que será feito em executarTestesRegionais
+				testCase.Category = dir.Name()
+				testCase.File = file
 				
 				testCases = append(testCases, testCase)
 			}
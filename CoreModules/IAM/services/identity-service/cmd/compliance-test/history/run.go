@@ -0,0 +1,103 @@
+// Package history grava os sumários das execuções de compliance e calcula a evolução das
+// pontuações, para os alertas do modo serve e para o subcomando scorecard
+package history
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/selection"
+)
+
+// Run é o sumário de uma região gravado no histórico de execuções
+type Run struct {
+	RunID                      string
+	Region                     string
+	RegionName                 string
+	Target                     string
+	PolicyRevision             string
+	TotalTests                 int
+	PassedTests                int
+	FailedTests                int
+	ComplianceScore            float64
+	FrameworkScores            map[string]report.FrameworkScore
+	RequirementsFailed         []string
+	CriticalRequirementsFailed []string
+	Failures                   []Failure
+	ExecutedAt                 time.Time
+	Duration                   int64
+}
+
+// Failure é um teste reprovado numa execução, gravado para o cálculo das violações
+// recorrentes e do tempo médio de remediação
+type Failure struct {
+	TestID       string   `json:"testId"`
+	Name         string   `json:"name"`
+	Requirements []string `json:"requirements,omitempty"`
+	Frameworks   []string `json:"frameworks,omitempty"`
+	Criticality  string   `json:"criticality,omitempty"`
+	Violations   []string `json:"violations,omitempty"`
+}
+
+// Store é o histórico das execuções usado por --history-db, pelo modo serve e pelo subcomando scorecard
+type Store interface {
+	Save(ctx context.Context, run Run) error
+	History(ctx context.Context, region string, limit int) ([]Run, error)
+	Regions(ctx context.Context) ([]string, error)
+	Close()
+}
+
+// NewRun extrai do sumário os dados gravados, incluindo os testes reprovados e os
+// requisitos de criticidade alta com testes reprovados
+func NewRun(runID, revisao string, summary *report.TestSummary) Run {
+	criticos := make(map[string]bool)
+	falhas := []Failure{}
+	for _, result := range summary.TestResults {
+		if result.Passed {
+			continue
+		}
+		falhas = append(falhas, Failure{
+			TestID:       result.TestCase.ID,
+			Name:         result.TestCase.Name,
+			Requirements: result.Requirements,
+			Frameworks:   result.Frameworks,
+			Criticality:  selection.NormalizeCriticality(result.Criticality),
+			Violations:   result.Violations,
+		})
+		if selection.NormalizeCriticality(result.Criticality) != "alta" {
+			continue
+		}
+		for _, reqID := range result.Requirements {
+			criticos[reqID] = true
+		}
+	}
+	sort.Slice(falhas, func(i, j int) bool { return falhas[i].TestID < falhas[j].TestID })
+	criticalFailed := make([]string, 0, len(criticos))
+	for reqID := range criticos {
+		criticalFailed = append(criticalFailed, reqID)
+	}
+	sort.Strings(criticalFailed)
+
+	requirementsFailed := append([]string{}, summary.RequirementsFailed...)
+	sort.Strings(requirementsFailed)
+
+	return Run{
+		RunID:                      runID,
+		Region:                     summary.Region,
+		RegionName:                 summary.RegionName,
+		Target:                     summary.Target,
+		PolicyRevision:             revisao,
+		TotalTests:                 summary.TotalTests,
+		PassedTests:                summary.PassedTests,
+		FailedTests:                summary.FailedTests,
+		ComplianceScore:            summary.ComplianceScore,
+		FrameworkScores:            summary.FrameworkScores,
+		RequirementsFailed:         requirementsFailed,
+		CriticalRequirementsFailed: criticalFailed,
+		Failures:                   falhas,
+		ExecutedAt:                 summary.ExecutedAt,
+		Duration:                   summary.Duration,
+	}
+}
@@ -0,0 +1,76 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// execucao cria uma execução da região com a pontuação geral e as pontuações por framework
+func execucao(runID, region string, executedAt time.Time, score float64, frameworks map[string]report.FrameworkScore) Run {
+	return Run{
+		RunID:           runID,
+		Region:          region,
+		Target:          "local",
+		ComplianceScore: score,
+		FrameworkScores: frameworks,
+		ExecutedAt:      executedAt,
+	}
+}
+
+func TestNewRun(t *testing.T) {
+	executedAt := time.Date(2026, 4, 2, 6, 0, 0, 0, time.UTC)
+	summary := &report.TestSummary{
+		Region:             "AO",
+		RegionName:         "Angola",
+		Target:             "pdp",
+		TotalTests:         4,
+		PassedTests:        1,
+		FailedTests:        3,
+		ComplianceScore:    25,
+		RequirementsFailed: []string{"ao-pd-req-02", "ao-aml-req-01"},
+		ExecutedAt:         executedAt,
+		Duration:           1200,
+		TestResults: []*report.TestResult{
+			{TestCase: report.TestCase{ID: "ao-pd-002", Name: "Consentimento"}, Criticality: "medium", Requirements: []string{"ao-pd-req-02"}},
+			{TestCase: report.TestCase{ID: "ao-aml-001", Name: "Triagem PEP"}, Criticality: "HIGH", Requirements: []string{"ao-aml-req-01", "ao-aml-req-03"}, Frameworks: []string{"BNA"}, Violations: []string{"PEP sem revisão"}},
+			{TestCase: report.TestCase{ID: "ao-aml-002"}, Criticality: "alta", Requirements: []string{"ao-aml-req-01"}},
+			{TestCase: report.TestCase{ID: "ao-pd-001"}, Passed: true, Criticality: "alta", Requirements: []string{"ao-pd-req-01"}},
+		},
+	}
+
+	run := NewRun("run-1", "rev-42", summary)
+
+	assert.Equal(t, "run-1", run.RunID)
+	assert.Equal(t, "rev-42", run.PolicyRevision)
+	assert.Equal(t, "pdp", run.Target)
+	assert.Equal(t, executedAt, run.ExecutedAt)
+
+	// Apenas os testes reprovados, ordenados por ID e com a criticidade normalizada
+	require.Len(t, run.Failures, 3)
+	assert.Equal(t, Failure{
+		TestID:       "ao-aml-001",
+		Name:         "Triagem PEP",
+		Requirements: []string{"ao-aml-req-01", "ao-aml-req-03"},
+		Frameworks:   []string{"BNA"},
+		Criticality:  "alta",
+		Violations:   []string{"PEP sem revisão"},
+	}, run.Failures[0])
+	assert.Equal(t, "ao-aml-002", run.Failures[1].TestID)
+	assert.Equal(t, "média", run.Failures[2].Criticality)
+
+	// Requisitos críticos: requisitos dos testes reprovados de criticidade alta, sem repetições
+	assert.Equal(t, []string{"ao-aml-req-01", "ao-aml-req-03"}, run.CriticalRequirementsFailed)
+
+	// Os requisitos reprovados são ordenados sem alterar o sumário
+	assert.Equal(t, []string{"ao-aml-req-01", "ao-pd-req-02"}, run.RequirementsFailed)
+	assert.Equal(t, []string{"ao-pd-req-02", "ao-aml-req-01"}, summary.RequirementsFailed)
+
+	// Sem reprovações, a lista de falhas é vazia e não nula, para ser gravada como []
+	run = NewRun("run-2", "", &report.TestSummary{Region: "AO"})
+	assert.NotNil(t, run.Failures)
+	assert.Empty(t, run.CriticalRequirementsFailed)
+}
@@ -0,0 +1,151 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// esquemaSQLite espelha iam.compliance_runs num arquivo local; listas e mapas são gravados em JSON
+const esquemaSQLite = `
+CREATE TABLE IF NOT EXISTS compliance_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id TEXT NOT NULL,
+	region TEXT NOT NULL,
+	region_name TEXT NOT NULL DEFAULT '',
+	target TEXT NOT NULL,
+	policy_revision TEXT NOT NULL DEFAULT '',
+	total_tests INTEGER NOT NULL,
+	passed_tests INTEGER NOT NULL,
+	failed_tests INTEGER NOT NULL,
+	compliance_score REAL NOT NULL,
+	framework_scores TEXT NOT NULL DEFAULT '{}',
+	requirements_failed TEXT NOT NULL DEFAULT '[]',
+	critical_requirements_failed TEXT NOT NULL DEFAULT '[]',
+	failures TEXT NOT NULL DEFAULT '[]',
+	executed_at INTEGER NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	UNIQUE (run_id, region)
+);
+CREATE INDEX IF NOT EXISTS idx_compliance_runs_region_executed ON compliance_runs(region, executed_at DESC);
+`
+
+// SQLiteStore grava o histórico das execuções num arquivo SQLite
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite abre (ou cria) o arquivo SQLite e garante o esquema
+func OpenSQLite(ctx context.Context, caminho string) (*SQLiteStore, error) {
+	if caminho == "" {
+		return nil, fmt.Errorf("caminho do histórico SQLite vazio")
+	}
+	db, err := sql.Open("sqlite3", caminho+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir histórico SQLite %s: %w", caminho, err)
+	}
+	if _, err := db.ExecContext(ctx, esquemaSQLite); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao preparar histórico SQLite %s: %w", caminho, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close fecha o arquivo SQLite
+func (r *SQLiteStore) Close() {
+	r.db.Close()
+}
+
+// Save acrescenta o sumário de uma região ao histórico
+func (r *SQLiteStore) Save(ctx context.Context, execucao Run) error {
+	colunas := make([]string, 0, 4)
+	for _, valor := range []interface{}{execucao.FrameworkScores, execucao.RequirementsFailed, execucao.CriticalRequirementsFailed, execucao.Failures} {
+		dados, err := json.Marshal(valor)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar execução de compliance da região %s: %w", execucao.Region, err)
+		}
+		colunas = append(colunas, string(dados))
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO compliance_runs (
+			run_id, region, region_name, target, policy_revision, total_tests, passed_tests, failed_tests,
+			compliance_score, framework_scores, requirements_failed, critical_requirements_failed,
+			failures, executed_at, duration_ms
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		execucao.RunID, execucao.Region, execucao.RegionName, execucao.Target, execucao.PolicyRevision,
+		execucao.TotalTests, execucao.PassedTests, execucao.FailedTests, execucao.ComplianceScore,
+		colunas[0], colunas[1], colunas[2], colunas[3], execucao.ExecutedAt.UnixMilli(), execucao.Duration)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar execução de compliance da região %s: %w", execucao.Region, err)
+	}
+	return nil
+}
+
+// History retorna as últimas execuções da região, da mais recente para a mais antiga
+func (r *SQLiteStore) History(ctx context.Context, region string, limite int) ([]Run, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT run_id, region, region_name, target, policy_revision, total_tests, passed_tests, failed_tests,
+			compliance_score, framework_scores, requirements_failed, critical_requirements_failed,
+			failures, executed_at, duration_ms
+		FROM compliance_runs
+		WHERE region = ?
+		ORDER BY executed_at DESC, id DESC
+		LIMIT ?`, region, limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar histórico de compliance da região %s: %w", region, err)
+	}
+	defer rows.Close()
+
+	var execucoes []Run
+	for rows.Next() {
+		var execucao Run
+		var frameworkScores, requirementsFailed, criticalFailed, falhas string
+		var executedAt int64
+		if err := rows.Scan(&execucao.RunID, &execucao.Region, &execucao.RegionName, &execucao.Target,
+			&execucao.PolicyRevision, &execucao.TotalTests, &execucao.PassedTests, &execucao.FailedTests,
+			&execucao.ComplianceScore, &frameworkScores, &requirementsFailed, &criticalFailed,
+			&falhas, &executedAt, &execucao.Duration); err != nil {
+			return nil, fmt.Errorf("erro ao ler histórico de compliance: %w", err)
+		}
+		for _, coluna := range []struct {
+			dados   string
+			destino interface{}
+		}{
+			{frameworkScores, &execucao.FrameworkScores},
+			{requirementsFailed, &execucao.RequirementsFailed},
+			{criticalFailed, &execucao.CriticalRequirementsFailed},
+			{falhas, &execucao.Failures},
+		} {
+			if err := json.Unmarshal([]byte(coluna.dados), coluna.destino); err != nil {
+				return nil, fmt.Errorf("erro ao decodificar histórico de compliance: %w", err)
+			}
+		}
+		execucao.ExecutedAt = time.UnixMilli(executedAt).UTC()
+		execucoes = append(execucoes, execucao)
+	}
+	return execucoes, rows.Err()
+}
+
+// Regions retorna as regiões com execuções gravadas
+func (r *SQLiteStore) Regions(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT region FROM compliance_runs ORDER BY region`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar regiões do histórico de compliance: %w", err)
+	}
+	defer rows.Close()
+
+	var regioes []string
+	for rows.Next() {
+		var region string
+		if err := rows.Scan(&region); err != nil {
+			return nil, fmt.Errorf("erro ao ler regiões do histórico de compliance: %w", err)
+		}
+		regioes = append(regioes, region)
+	}
+	return regioes, rows.Err()
+}
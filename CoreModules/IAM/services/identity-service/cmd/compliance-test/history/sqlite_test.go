@@ -0,0 +1,69 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	caminho := filepath.Join(t.TempDir(), "historico.db")
+
+	store, err := OpenSQLite(ctx, caminho)
+	require.NoError(t, err)
+
+	base := time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)
+	primeira := execucao("run-1", "AO", base, 80, map[string]report.FrameworkScore{
+		"BNA": {ID: "BNA", Name: "Aviso BNA", TotalTests: 5, PassedTests: 4, FailedTests: 1, ComplianceScore: 80},
+	})
+	primeira.RegionName = "Angola"
+	primeira.PolicyRevision = "rev-1"
+	primeira.RequirementsFailed = []string{"ao-aml-req-01"}
+	primeira.CriticalRequirementsFailed = []string{"ao-aml-req-01"}
+	primeira.Failures = []Failure{{TestID: "ao-aml-001", Name: "Triagem PEP", Criticality: "alta", Violations: []string{"PEP sem revisão"}}}
+	primeira.Duration = 1500
+
+	require.NoError(t, store.Save(ctx, primeira))
+	require.NoError(t, store.Save(ctx, execucao("run-2", "AO", base.Add(6*time.Hour), 90, nil)))
+	require.NoError(t, store.Save(ctx, execucao("run-3", "AO", base.Add(12*time.Hour), 100, nil)))
+	require.NoError(t, store.Save(ctx, execucao("run-1", "BR", base, 70, nil)))
+
+	// Cada região é gravada uma única vez por execução
+	assert.Error(t, store.Save(ctx, execucao("run-1", "AO", base, 80, nil)))
+	store.Close()
+
+	// O histórico persiste no arquivo e é relido da execução mais recente para a mais antiga
+	store, err = OpenSQLite(ctx, caminho)
+	require.NoError(t, err)
+	defer store.Close()
+
+	regioes, err := store.Regions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AO", "BR"}, regioes)
+
+	historico, err := store.History(ctx, "AO", 2)
+	require.NoError(t, err)
+	require.Len(t, historico, 2)
+	assert.Equal(t, "run-3", historico[0].RunID)
+	assert.Equal(t, "run-2", historico[1].RunID)
+
+	historico, err = store.History(ctx, "AO", 10)
+	require.NoError(t, err)
+	require.Len(t, historico, 3)
+	assert.Equal(t, primeira, historico[2], "listas e mapas gravados em JSON são restaurados")
+
+	historico, err = store.History(ctx, "MZ", 10)
+	require.NoError(t, err)
+	assert.Empty(t, historico)
+}
+
+func TestOpenSQLiteEmptyPath(t *testing.T) {
+	_, err := OpenSQLite(context.Background(), "")
+	assert.Error(t, err)
+}
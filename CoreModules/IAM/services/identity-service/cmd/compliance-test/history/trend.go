@@ -0,0 +1,104 @@
+package history
+
+// FrameworkTrend é a evolução da pontuação de um framework nas últimas execuções
+type FrameworkTrend struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Score    float64  `json:"score"`
+	Previous *float64 `json:"previous,omitempty"`
+	Delta    float64  `json:"delta"`
+	Average  float64  `json:"average"`
+}
+
+// Trend é a evolução da pontuação de compliance de uma região nas últimas execuções
+type Trend struct {
+	Region     string                    `json:"region"`
+	Runs       int                       `json:"runs"`
+	Score      float64                   `json:"score"`
+	Previous   *float64                  `json:"previous,omitempty"`
+	Delta      float64                   `json:"delta"`
+	Average    float64                   `json:"average"`
+	Slope      float64                   `json:"slopePerRun"` // Variação média por execução (mínimos quadrados)
+	Frameworks map[string]FrameworkTrend `json:"frameworks"`
+}
+
+// CalculateTrend calcula a evolução das pontuações a partir do histórico da região,
+// ordenado da execução mais recente para a mais antiga
+func CalculateTrend(historico []Run) *Trend {
+	if len(historico) == 0 {
+		return nil
+	}
+
+	atual := historico[0]
+	tendencia := &Trend{
+		Region:     atual.Region,
+		Runs:       len(historico),
+		Score:      atual.ComplianceScore,
+		Frameworks: make(map[string]FrameworkTrend, len(atual.FrameworkScores)),
+	}
+
+	pontuacoes := make([]float64, len(historico))
+	for i, execucao := range historico {
+		pontuacoes[i] = execucao.ComplianceScore
+	}
+	tendencia.Average = Mean(pontuacoes)
+	tendencia.Slope = declive(pontuacoes)
+	if len(historico) > 1 {
+		anterior := historico[1].ComplianceScore
+		tendencia.Previous = &anterior
+		tendencia.Delta = atual.ComplianceScore - anterior
+	}
+
+	for id, score := range atual.FrameworkScores {
+		if score.TotalTests == 0 {
+			continue
+		}
+		framework := FrameworkTrend{ID: id, Name: score.Name, Score: score.ComplianceScore}
+		var serie []float64
+		for i, execucao := range historico {
+			anterior, ok := execucao.FrameworkScores[id]
+			if !ok || anterior.TotalTests == 0 {
+				continue
+			}
+			serie = append(serie, anterior.ComplianceScore)
+			if i == 1 {
+				valor := anterior.ComplianceScore
+				framework.Previous = &valor
+				framework.Delta = score.ComplianceScore - valor
+			}
+		}
+		framework.Average = Mean(serie)
+		tendencia.Frameworks[id] = framework
+	}
+	return tendencia
+}
+
+// Mean retorna a média dos valores
+func Mean(valores []float64) float64 {
+	if len(valores) == 0 {
+		return 0
+	}
+	var soma float64
+	for _, v := range valores {
+		soma += v
+	}
+	return soma / float64(len(valores))
+}
+
+// declive retorna a variação média por execução da série, ordenada da mais recente para a mais antiga;
+// um declive negativo indica que a pontuação está a descer
+func declive(valores []float64) float64 {
+	n := float64(len(valores))
+	if n < 2 {
+		return 0
+	}
+	var somaX, somaY, somaXY, somaXX float64
+	for i, y := range valores {
+		x := n - 1 - float64(i) // A execução mais antiga é x=0
+		somaX += x
+		somaY += y
+		somaXY += x * y
+		somaXX += x * x
+	}
+	return (n*somaXY - somaX*somaY) / (n*somaXX - somaX*somaX)
+}
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateTrend(t *testing.T) {
+	assert.Nil(t, CalculateTrend(nil))
+
+	base := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	historico := []Run{
+		execucao("r3", "AO", base.Add(72*time.Hour), 95, map[string]report.FrameworkScore{
+			"BNA":  {Name: "Aviso BNA", TotalTests: 4, ComplianceScore: 100},
+			"FATF": {TotalTests: 2, ComplianceScore: 50},
+			"GDPR": {TotalTests: 0},
+		}),
+		execucao("r2", "AO", base.Add(48*time.Hour), 90, map[string]report.FrameworkScore{
+			"BNA": {TotalTests: 4, ComplianceScore: 75},
+		}),
+		execucao("r1", "AO", base.Add(24*time.Hour), 85, map[string]report.FrameworkScore{
+			"BNA":  {TotalTests: 4, ComplianceScore: 50},
+			"FATF": {TotalTests: 2, ComplianceScore: 100},
+		}),
+		execucao("r0", "AO", base, 80, nil),
+	}
+
+	tendencia := CalculateTrend(historico)
+	require.NotNil(t, tendencia)
+	assert.Equal(t, "AO", tendencia.Region)
+	assert.Equal(t, 4, tendencia.Runs)
+	assert.Equal(t, 95.0, tendencia.Score)
+	require.NotNil(t, tendencia.Previous)
+	assert.Equal(t, 90.0, *tendencia.Previous)
+	assert.Equal(t, 5.0, tendencia.Delta)
+	assert.Equal(t, 87.5, tendencia.Average)
+	assert.InDelta(t, 5.0, tendencia.Slope, 1e-9, "a pontuação sobe 5 pontos por execução")
+
+	// Frameworks sem testes na execução atual não têm tendência
+	require.Len(t, tendencia.Frameworks, 2)
+	bna := tendencia.Frameworks["BNA"]
+	assert.Equal(t, "Aviso BNA", bna.Name)
+	require.NotNil(t, bna.Previous)
+	assert.Equal(t, 25.0, bna.Delta)
+	assert.Equal(t, 75.0, bna.Average)
+
+	// Sem pontuação na execução anterior não há variação; a média usa as execuções onde existe
+	fatf := tendencia.Frameworks["FATF"]
+	assert.Nil(t, fatf.Previous)
+	assert.Equal(t, 0.0, fatf.Delta)
+	assert.Equal(t, 75.0, fatf.Average)
+
+	// Uma única execução não tem variação nem declive
+	tendencia = CalculateTrend(historico[3:])
+	assert.Nil(t, tendencia.Previous)
+	assert.Equal(t, 0.0, tendencia.Slope)
+}
+
+func TestDeclive(t *testing.T) {
+	tests := []struct {
+		nome     string
+		valores  []float64
+		esperado float64
+	}{
+		{"sem execuções", nil, 0},
+		{"uma execução", []float64{90}, 0},
+		{"pontuação estável", []float64{90, 90, 90}, 0},
+		{"pontuação a descer", []float64{70, 80, 90}, -10},
+		{"pontuação a subir", []float64{100, 90}, 10},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.esperado, declive(tt.valores), 1e-9, tt.nome)
+	}
+}
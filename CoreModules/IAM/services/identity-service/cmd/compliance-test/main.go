@@ -14,92 +14,25 @@ import (
 	"github.com/fatih/color"
 	"github.com/innovabiz/iam/logging"
 	"github.com/innovabiz/iam/telemetry"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
 	"github.com/innovabizdevops/innovabiz-iam/remediator"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/olekukonko/tablewriter"
 	"go.uber.org/zap"
 )
 
-// Estruturas para os testes de compliance e matrizes
-type ComplianceMatrix struct {
-	RegionCode     string        `json:"regionCode"`
-	RegionName     string        `json:"regionName"`
-	CrossRegional  bool          `json:"crossRegional"`
-	Frameworks     []Framework   `json:"frameworks"`
-	Requirements   []Requirement `json:"requirements"`
-}
-
-type Framework struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Version     string   `json:"version"`
-	Description string   `json:"description"`
-	References  []string `json:"references"`
-}
-
-type Requirement struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Description  string   `json:"description"`
-	FrameworkID  string   `json:"frameworkId"`
-	ArticleRefs  []string `json:"articleRefs"`
-	Criticality  string   `json:"criticality"`
-	TestCaseIDs  []string `json:"testCaseIds"`
-}
-
-type TestCase struct {
-	ID               string            `json:"id"`
-	Name             string            `json:"name"`
-	Description      string            `json:"description"`
-	RequirementIDs   []string          `json:"requirementIds"`
-	PolicyPath       string            `json:"policyPath"`
-	Input            interface{}       `json:"input"`
-	ExpectedDecision interface{}       `json:"expectedDecision"`
-	Tags             []string          `json:"tags"`
-	Context          map[string]string `json:"context"`
-}
-
-type TestResult struct {
-	TestCase         TestCase         `json:"testCase"`
-	ActualDecision   interface{}      `json:"actualDecision"`
-	Passed           bool             `json:"passed"`
-	Message          string           `json:"message,omitempty"`
-	ExecutionTimeMs  int64            `json:"executionTimeMs"`
-	PolicyPath       string           `json:"policyPath"`
-	Requirements     []string         `json:"requirements"`
-	Frameworks       []string         `json:"frameworks"`
-	Criticality      string           `json:"criticality"`
-	ComplianceRegion string           `json:"complianceRegion"`
-	ExecutedAt       time.Time        `json:"executedAt"`
-	Violations       []string         `json:"violations,omitempty"`
-	Tags             []string         `json:"tags"`
-}
-
-type TestSummary struct {
-	Region                string                    `json:"region"`
-	RegionName            string                    `json:"regionName"`
-	TotalTests            int                       `json:"totalTests"`
-	PassedTests           int                       `json:"passedTests"`
-	FailedTests           int                       `json:"failedTests"`
-	ComplianceScore       float64                   `json:"complianceScore"`
-	FrameworkScores       map[string]FrameworkScore `json:"frameworkScores"`
-	RequirementsMet       []string                  `json:"requirementsMet"`
-	RequirementsFailed    []string                  `json:"requirementsFailed"`
-	TestResults           []*TestResult             `json:"testResults"`
-	ExecutedAt            time.Time                 `json:"executedAt"`
-	Duration              int64                     `json:"durationMs"`
-	RemediationApplied    bool                      `json:"remediationApplied,omitempty"`
-	RemediationResult     *RemediationResult        `json:"remediationResult,omitempty"`
-}
-
-type FrameworkScore struct {
-	ID               string  `json:"id"`
-	Name             string  `json:"name"`
-	TotalTests       int     `json:"totalTests"`
-	PassedTests      int     `json:"passedTests"`
-	FailedTests      int     `json:"failedTests"`
-	ComplianceScore  float64 `json:"complianceScore"`
-}
+// Estruturas para os testes de compliance e matrizes, partilhadas com o pacote report
+type (
+	ComplianceMatrix = report.ComplianceMatrix
+	Framework        = report.Framework
+	Requirement      = report.Requirement
+	TestCase         = report.TestCase
+	TestResult       = report.TestResult
+	TestSummary      = report.TestSummary
+	FrameworkScore   = report.FrameworkScore
+)
 
 // Configurações da CLI
 type Config struct {
@@ -108,7 +41,7 @@ type Config struct {
 	OutputDir                string
 	Regions                  []string
 	Frameworks               []string
-	Tags                     []string
+	Tags                     string // Expressão booleana de tags
 	ReportFormat             string
 	Verbose                  bool
 	Remediate                bool
@@ -123,9 +56,60 @@ type Config struct {
 	IgnoreTypes              []string
 	RequireApproval          bool
 	MaxRemediationsPerPolicy int
+	
+	// Bundles OPA e documentos de dados
+	BundlePath               string
+	BundleVerifyKey          string
+	BundleVerifyKeyID        string
+	BundleVerifyAlg          string
+	BundleVerifyScope        string
+	BundleSkipVerify         bool
+	DataPaths                []string
+	
+	// Relatório HTML
+	HistoryRuns              int
+	
+	// Seleção de casos de teste
+	ExcludeFiles             []string
+	Shard                    string
+	ListOnly                 bool
+	
+	// Log de decisões OPA (NDJSON) para o subcomando replay
+	DecisionLogPath          string
+	
+	// Alvo de execução: avaliação local ou na API de decisões de um PDP em execução
+	Target                   string
+	PDPURL                   string
+	PDPToken                 string
+	PDPTimeout               time.Duration
+	
+	// Histórico das execuções (SQLite ou Postgres) para o subcomando scorecard
+	HistoryDB                string
+	
+	// Sumário para comentários de pull request e anotações do GitHub Actions
+	SummaryFormat            string
+	SummaryPath              string
+	BaselineDir              string
+	RequirementURL           string
+	GitHubAnnotations        bool
 }
 
 func main() {
+	// Subcomando de reavaliação de um log de decisões contra uma nova versão das políticas
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+	
+	// Modo serve: execução agendada das suítes regionais com histórico no Postgres e alertas de desvio
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+	
+	// Scorecard de governação: tendências, violações recorrentes e tempo médio de remediação
+	if len(os.Args) > 1 && os.Args[1] == "scorecard" {
+		os.Exit(runScorecard(os.Args[2:]))
+	}
+	
 	// Configuração da CLI
 	config := parseFlags()
 	
@@ -140,8 +124,45 @@ func main() {
 	logger.Info("Iniciando testes de compliance",
 		zap.Strings("regions", config.Regions),
 		zap.Strings("frameworks", config.Frameworks),
-		zap.Strings("tags", config.Tags),
-		zap.String("opa_path", config.OPAPath))
+		zap.String("tags", config.Tags),
+		zap.String("opa_path", config.OPAPath),
+		zap.String("target", config.Target))
+	
+	if err := validarAlvo(config); err != nil {
+		logger.Error("Opções de execução inválidas", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := validarSumarioPR(config); err != nil {
+		logger.Error("Opções de execução inválidas", zap.Error(err))
+		os.Exit(1)
+	}
+	if config.HistoryDB != "" && config.Shard != "" {
+		logger.Error("Opções de execução inválidas", zap.Error(fmt.Errorf("--history-db grava o sumário completo de cada região e não se aplica a --shard")))
+		os.Exit(1)
+	}
+	
+	// Prepara a seleção de casos de teste (expressão de tags, exclusões e shard)
+	seletor, err := novoSeletorTestes(config)
+	if err != nil {
+		logger.Error("Erro na seleção de casos de teste", zap.Error(err))
+		os.Exit(1)
+	}
+	if seletor.Expression() != nil {
+		logger.Debug("Expressão de tags", zap.Stringer("expression", seletor.Expression()))
+	}
+	
+	// Apenas lista a seleção, sem avaliar políticas
+	if config.ListOnly {
+		for _, region := range config.Regions {
+			_, testCases, err := selecionarCasosRegiao(config, seletor, region)
+			if err != nil {
+				logger.Error("Erro ao selecionar casos de teste", zap.String("region", region), zap.Error(err))
+				os.Exit(1)
+			}
+			listarCasosSelecionados(os.Stdout, region, testCases)
+		}
+		return
+	}
 	
 	// Status da remediação
 	if config.Remediate {
@@ -154,8 +175,106 @@ func main() {
 		}
 	}
 
+	// Carrega o bundle OPA (quando informado) uma única vez para todas as regiões
+	env, err := carregarAmbientePoliticas(logger, config)
+	if err != nil {
+		logger.Error("Erro ao preparar políticas OPA", zap.Error(err))
+		os.Exit(1)
+	}
+	
+	// Abre o log de decisões OPA, quando solicitado, partilhado por todas as regiões
+	env.decisoes, err = decisionlog.Create(config.DecisionLogPath)
+	if err != nil {
+		logger.Error("Erro ao preparar log de decisões", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Abre o histórico das execuções, quando solicitado; as regiões partilham o ID da execução
+	var historico history.Store
+	var runID, revisao string
+	falhaHistorico := false
+	if config.HistoryDB != "" {
+		historico, err = abrirRegistoTendencias(context.Background(), config.HistoryDB)
+		if err != nil {
+			logger.Error("Erro ao abrir o histórico de execuções", zap.Error(err))
+			os.Exit(1)
+		}
+		if runID, err = novoIDExecucao(); err != nil {
+			logger.Error("Erro ao iniciar execução", zap.Error(err))
+			os.Exit(1)
+		}
+		if env.bundle != nil {
+			revisao = env.bundle.Manifest.Revision
+		}
+	}
+
+	// Carrega as execuções de referência dos deltas antes de os testes gravarem os relatórios atuais
+	var baselines map[string]*TestSummary
+	if config.SummaryFormat == formatoMarkdown || config.GitHubAnnotations {
+		baselines, err = carregarBaselines(config)
+		if err != nil {
+			logger.Error("Erro ao carregar execução de referência", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
 	// Executa os testes para cada região selecionada
+	var summaries []*TestSummary
 	for _, region := range config.Regions {
-		executarTestesRegionais(logger, config, region)
+		summary := executarTestesRegionais(logger, config, env, seletor, region)
+		if summary != nil {
+			summaries = append(summaries, summary)
+		}
+		if historico == nil || summary == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), tempoLimiteRegistoPadrao)
+		if err := historico.Save(ctx, history.NewRun(runID, revisao, summary)); err != nil {
+			logger.Error("Erro ao gravar sumário no histórico de execuções", zap.String("region", region), zap.Error(err))
+			falhaHistorico = true
+		}
+		cancel()
+	}
+	if historico != nil {
+		historico.Close()
+	}
+	
+	// Anotações do GitHub Actions para os testes reprovados
+	if config.GitHubAnnotations {
+		for _, summary := range summaries {
+			emitirAnotacoesGitHub(os.Stdout, summary, novasFalhas(summary, baselines[summary.Region]))
+		}
+	}
+	
+	// Sumário compacto para o comentário do pull request
+	if config.SummaryFormat == formatoMarkdown {
+		path := config.SummaryPath
+		if path == "" {
+			path = filepath.Join(config.OutputDir, sumarioMarkdownPadrao)
+		}
+		if err := gravarSumarioMarkdown(path, gerarSumarioMarkdown(summaries, baselines, config.RequirementURL)); err != nil {
+			logger.Error("Erro ao gravar sumário Markdown", zap.Error(err))
+			os.Exit(1)
+		}
+		if path != "-" {
+			logger.Info("Sumário Markdown gravado", zap.String("path", path))
+		}
+	}
+	
+	if err := env.decisoes.Close(); err != nil {
+		logger.Error("Erro ao fechar log de decisões", zap.Error(err))
+		os.Exit(1)
+	}
+	if config.DecisionLogPath != "" {
+		logger.Info("Log de decisões OPA gravado", zap.String("path", config.DecisionLogPath))
+	}
+	
+	// Com --bundle e --target pdp, confirma que o PDP avalia a mesma revisão do bundle de origem
+	if !verificarRevisaoPDP(logger, env) {
+		os.Exit(exitRevisaoDivergente)
+	}
+	
+	if falhaHistorico {
+		os.Exit(1)
 	}
 }
\ No newline at end of file
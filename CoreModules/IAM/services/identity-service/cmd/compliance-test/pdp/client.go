@@ -0,0 +1,185 @@
+// Package pdp avalia os casos de teste na API de decisões (/v1/data) de um PDP OPA em execução
+package pdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limites dos pedidos ao PDP
+const (
+	DefaultTimeout = 10 * time.Second // Tempo máximo de espera por cada decisão
+	maxResposta    = 10 << 20         // Tamanho máximo do corpo de resposta lido por decisão
+)
+
+// Client é o cliente da API de decisões de um PDP OPA
+type Client struct {
+	baseURL *url.URL
+	token   string
+	http    *http.Client
+
+	mu       sync.Mutex
+	revisoes map[string]string // Revisão de cada bundle ativo no PDP, reportada na proveniência
+}
+
+// resposta é a resposta da API de decisões do OPA
+type resposta struct {
+	Result     *json.RawMessage `json:"result"`
+	DecisionID string           `json:"decision_id"`
+	Provenance *struct {
+		Version  string `json:"version"`
+		Revision string `json:"revision"`
+		Bundles  map[string]struct {
+			Revision string `json:"revision"`
+		} `json:"bundles"`
+	} `json:"provenance"`
+}
+
+// erroAPI é o corpo de erro da API do OPA
+type erroAPI struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Decision é a decisão devolvida pelo PDP para um caso de teste
+type Decision struct {
+	Result     interface{} // nil quando a decisão é indefinida no PDP
+	DecisionID string
+	Defined    bool
+}
+
+// New cria o cliente da API de decisões do PDP em rawURL, autenticado com o token quando indicado
+func New(rawURL, token string, timeout time.Duration) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimRight(rawURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("URL do PDP inválida: %w", err)
+	}
+	if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return nil, fmt.Errorf("URL do PDP inválida %q: indique http(s)://host[:porta]", rawURL)
+	}
+
+	return &Client{
+		baseURL:  baseURL,
+		token:    token,
+		http:     &http.Client{Timeout: timeout},
+		revisoes: make(map[string]string),
+	}, nil
+}
+
+// Evaluate pede ao PDP a decisão da política para o input do caso de teste
+// A decisão é descodificada como a decisão esperada do caso de teste, para que a comparação
+// pela serialização JSON não dependa da forma como o PDP escreve os números
+func (c *Client) Evaluate(ctx context.Context, policyPath string, input interface{}) (*Decision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar input: %w", err)
+	}
+
+	endpoint := *c.baseURL
+	endpoint.Path = c.baseURL.Path + "/v1/data/" + strings.Trim(policyPath, "/")
+	endpoint.RawQuery = "provenance=true"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao preparar pedido ao PDP: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contactar o PDP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResposta))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta do PDP: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e erroAPI
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return nil, fmt.Errorf("PDP respondeu %d (%s): %s", resp.StatusCode, e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("PDP respondeu %d", resp.StatusCode)
+	}
+
+	var r resposta
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("resposta do PDP inválida: %w", err)
+	}
+
+	decisao := &Decision{DecisionID: r.DecisionID}
+	if r.Result != nil {
+		if err := json.Unmarshal(*r.Result, &decisao.Result); err != nil {
+			return nil, fmt.Errorf("decisão do PDP inválida: %w", err)
+		}
+		decisao.Defined = true
+	}
+
+	if r.Provenance != nil {
+		c.mu.Lock()
+		for nome, b := range r.Provenance.Bundles {
+			c.revisoes[nome] = b.Revision
+		}
+		if len(r.Provenance.Bundles) == 0 && r.Provenance.Revision != "" {
+			c.revisoes[""] = r.Provenance.Revision
+		}
+		c.mu.Unlock()
+	}
+
+	return decisao, nil
+}
+
+// BundleRevisions retorna a revisão de cada bundle ativo no PDP, observada nas respostas
+func (c *Client) BundleRevisions() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	revisoes := make(map[string]string, len(c.revisoes))
+	for nome, revisao := range c.revisoes {
+		revisoes[nome] = revisao
+	}
+	return revisoes
+}
+
+// Revision retorna as revisões dos bundles do PDP numa única linha, para o log de decisões
+func (c *Client) Revision() string {
+	return FormatRevisions(c.BundleRevisions())
+}
+
+// FormatRevisions apresenta as revisões dos bundles como nome=revisão, ordenadas pelo nome
+func FormatRevisions(revisoes map[string]string) string {
+	if len(revisoes) == 0 {
+		return "não reportadas"
+	}
+
+	nomes := make([]string, 0, len(revisoes))
+	for nome := range revisoes {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+
+	partes := make([]string, 0, len(nomes))
+	for _, nome := range nomes {
+		if nome == "" {
+			partes = append(partes, revisoes[nome])
+		} else {
+			partes = append(partes, nome+"="+revisoes[nome])
+		}
+	}
+	return strings.Join(partes, ", ")
+}
@@ -0,0 +1,154 @@
+package pdp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pedidoPDP regista o último pedido recebido pelo PDP de teste
+type pedidoPDP struct {
+	method        string
+	path          string
+	query         string
+	authorization string
+	contentType   string
+	body          map[string]interface{}
+}
+
+// novoPDP inicia um PDP de teste que responde com o estado e o corpo indicados
+func novoPDP(t *testing.T, status int, resposta string) (*httptest.Server, *pedidoPDP) {
+	t.Helper()
+	pedido := &pedidoPDP{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pedido.method = r.Method
+		pedido.path = r.URL.Path
+		pedido.query = r.URL.RawQuery
+		pedido.authorization = r.Header.Get("Authorization")
+		pedido.contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		pedido.body = nil
+		_ = json.Unmarshal(data, &pedido.body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(resposta))
+	}))
+	t.Cleanup(server.Close)
+	return server, pedido
+}
+
+func TestNew(t *testing.T) {
+	client, err := New("https://pdp.innovabiz.ao:8181/opa/", "", DefaultTimeout)
+	require.NoError(t, err)
+	assert.Equal(t, "/opa", client.baseURL.Path, "a barra final é removida")
+	assert.Equal(t, DefaultTimeout, client.http.Timeout)
+
+	for _, invalida := range []string{"pdp.innovabiz.ao:8181", "ftp://pdp", "http://", "http://[::1"} {
+		_, err := New(invalida, "", DefaultTimeout)
+		assert.Error(t, err, invalida)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	server, pedido := novoPDP(t, http.StatusOK, `{
+		"decision_id": "7f3a",
+		"result": {"allow": false, "score": 10},
+		"provenance": {"version": "0.60.0", "bundles": {"compliance": {"revision": "v42"}, "dados": {"revision": "d7"}}}
+	}`)
+
+	client, err := New(server.URL+"/", "segredo", time.Second)
+	require.NoError(t, err)
+
+	decisao, err := client.Evaluate(context.Background(), "/aml/angola/pep/", map[string]interface{}{"pep": true})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, pedido.method)
+	assert.Equal(t, "/v1/data/aml/angola/pep", pedido.path)
+	assert.Equal(t, "provenance=true", pedido.query)
+	assert.Equal(t, "Bearer segredo", pedido.authorization)
+	assert.Equal(t, "application/json", pedido.contentType)
+	assert.Equal(t, map[string]interface{}{"input": map[string]interface{}{"pep": true}}, pedido.body)
+
+	assert.True(t, decisao.Defined)
+	assert.Equal(t, "7f3a", decisao.DecisionID)
+	assert.Equal(t, map[string]interface{}{"allow": false, "score": float64(10)}, decisao.Result)
+
+	assert.Equal(t, map[string]string{"compliance": "v42", "dados": "d7"}, client.BundleRevisions())
+	assert.Equal(t, "compliance=v42, dados=d7", client.Revision())
+}
+
+func TestEvaluateUndefined(t *testing.T) {
+	server, pedido := novoPDP(t, http.StatusOK, `{"decision_id": "8b1c", "provenance": {"revision": "v41"}}`)
+
+	client, err := New(server.URL, "", time.Second)
+	require.NoError(t, err)
+
+	decisao, err := client.Evaluate(context.Background(), "lgpd/consent", nil)
+	require.NoError(t, err)
+	assert.False(t, decisao.Defined, "sem result a decisão é indefinida")
+	assert.Nil(t, decisao.Result)
+	assert.Equal(t, "8b1c", decisao.DecisionID)
+	assert.Empty(t, pedido.authorization, "sem token não há cabeçalho de autorização")
+
+	// Sem bundles, a revisão global da proveniência é registada sem nome
+	assert.Equal(t, map[string]string{"": "v41"}, client.BundleRevisions())
+	assert.Equal(t, "v41", client.Revision())
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		nome     string
+		status   int
+		resposta string
+		erro     string
+	}{
+		{"erro da API do OPA", http.StatusBadRequest, `{"code": "invalid_parameter", "message": "input inválido"}`, "PDP respondeu 400 (invalid_parameter): input inválido"},
+		{"erro sem corpo", http.StatusServiceUnavailable, "", "PDP respondeu 503"},
+		{"resposta inválida", http.StatusOK, "<html>", "resposta do PDP inválida"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			server, _ := novoPDP(t, tt.status, tt.resposta)
+			client, err := New(server.URL, "", time.Second)
+			require.NoError(t, err)
+
+			decisao, err := client.Evaluate(context.Background(), "aml/pep", nil)
+			require.Error(t, err)
+			assert.Nil(t, decisao)
+			assert.Contains(t, err.Error(), tt.erro)
+			assert.Empty(t, client.BundleRevisions())
+		})
+	}
+}
+
+func TestEvaluateTimeout(t *testing.T) {
+	bloquear := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-bloquear
+	}))
+	defer server.Close()
+	defer close(bloquear)
+
+	client, err := New(server.URL, "", 50*time.Millisecond)
+	require.NoError(t, err)
+
+	_, err = client.Evaluate(context.Background(), "aml/pep", nil)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "erro ao contactar o PDP"), err.Error())
+}
+
+func TestFormatRevisions(t *testing.T) {
+	assert.Equal(t, "não reportadas", FormatRevisions(nil))
+	assert.Equal(t, "v1", FormatRevisions(map[string]string{"": "v1"}))
+	assert.Equal(t, "a=1, b=2", FormatRevisions(map[string]string{"b": "2", "a": "1"}))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// Alvos de execução dos casos de teste
+const (
+	alvoLocal = "local" // Avaliação local das políticas Rego (ou do bundle)
+	alvoPDP   = "pdp"   // Avaliação remota na API de decisões de um PDP em execução
+)
+
+// Código de saída quando a revisão do bundle no PDP difere da revisão do bundle de origem
+const exitRevisaoDivergente = 4
+
+// validarAlvo verifica a combinação do alvo de execução com as restantes opções
+func validarAlvo(config Config) error {
+	switch config.Target {
+	case alvoLocal:
+		if config.PDPURL != "" {
+			return fmt.Errorf("--pdp-url requer --target %s", alvoPDP)
+		}
+		return nil
+	case alvoPDP:
+		if config.PDPURL == "" {
+			return fmt.Errorf("--target %s requer --pdp-url", alvoPDP)
+		}
+		if config.Remediate {
+			return fmt.Errorf("--remediate altera as políticas locais e não se aplica a --target %s", alvoPDP)
+		}
+		if len(config.DataPaths) > 0 {
+			return fmt.Errorf("--data não se aplica a --target %s: o PDP usa os seus próprios documentos de dados", alvoPDP)
+		}
+		if config.PDPTimeout <= 0 {
+			return fmt.Errorf("--pdp-timeout deve ser positivo")
+		}
+		return nil
+	default:
+		return fmt.Errorf("alvo de execução desconhecido %q (use %s ou %s)", config.Target, alvoLocal, alvoPDP)
+	}
+}
+
+// verificarRevisaoPDP compara a revisão do bundle de origem (--bundle) com as revisões ativas no PDP
+// Retorna false quando nenhum bundle do PDP tem a revisão do bundle de origem
+func verificarRevisaoPDP(logger *zap.Logger, env *ambientePoliticas) bool {
+	if env.pdp == nil || env.bundle == nil {
+		return true
+	}
+
+	esperada := env.bundle.Manifest.Revision
+	if esperada == "" {
+		logger.Warn("O bundle de origem não tem revisão no manifesto; não é possível compará-la com o PDP")
+		return true
+	}
+	revisoes := env.pdp.BundleRevisions()
+	if len(revisoes) == 0 {
+		logger.Warn("O PDP não reportou a revisão dos bundles; não é possível compará-la com o bundle de origem",
+			zap.String("expected", esperada))
+		return true
+	}
+
+	for _, revisao := range revisoes {
+		if revisao == esperada {
+			logger.Info("Revisão do bundle no PDP corresponde ao bundle de origem",
+				zap.String("revision", esperada))
+			return true
+		}
+	}
+
+	logger.Error("Revisão do bundle no PDP difere do bundle de origem",
+		zap.String("expected", esperada),
+		zap.String("pdp", env.pdp.Revision()))
+	return false
+}
@@ -0,0 +1,95 @@
+package policybundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const politicaTeste = `package iam.teste
+
+import future.keywords.if
+
+allow if input.user == "admin"
+`
+
+// escreverBundle grava um bundle .tar.gz com uma política, assinado com HS256 quando há segredo
+func escreverBundle(t *testing.T, segredo string) string {
+	t.Helper()
+
+	roots := []string{"iam"}
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "v1", Roots: &roots},
+		Data:     map[string]interface{}{},
+		Modules: []bundle.ModuleFile{{
+			URL:  "/iam/teste.rego",
+			Path: "/iam/teste.rego",
+			Raw:  []byte(politicaTeste),
+		}},
+	}
+
+	if segredo != "" {
+		require.NoError(t, b.GenerateSignature(bundle.NewSigningConfig(segredo, "HS256", ""), "default", false))
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, bundle.NewWriter(f).Write(b))
+	return path
+}
+
+// escreverChave grava o segredo partilhado usado na verificação
+func escreverChave(t *testing.T, segredo string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.key")
+	require.NoError(t, os.WriteFile(path, []byte(segredo), 0600))
+	return path
+}
+
+func TestCarregarBundleSemChaveRejeitado(t *testing.T) {
+	for _, segredo := range []string{"", "segredo-de-assinatura"} {
+		_, err := Load(Options{Path: escreverBundle(t, segredo)})
+		assert.ErrorIs(t, err, ErrVerifyKeyRequired, "bundle sem --bundle-verify-key deve ser rejeitado (assinado=%v)", segredo != "")
+	}
+}
+
+func TestCarregarBundleSkipVerifyExplicito(t *testing.T) {
+	b, err := Load(Options{Path: escreverBundle(t, ""), SkipVerify: true})
+	require.NoError(t, err)
+	assert.Equal(t, "v1", b.Manifest.Revision)
+	assert.Len(t, b.Modules, 1)
+}
+
+func TestCarregarBundleVerificaAssinatura(t *testing.T) {
+	tests := []struct {
+		nome          string
+		segredoBundle string
+		segredoChave  string
+		valido        bool
+	}{
+		{nome: "assinatura válida", segredoBundle: "segredo-de-assinatura", segredoChave: "segredo-de-assinatura", valido: true},
+		{nome: "chave diferente", segredoBundle: "segredo-de-assinatura", segredoChave: "outro-segredo"},
+		{nome: "bundle não assinado", segredoChave: "segredo-de-assinatura"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			_, err := Load(Options{
+				Path:      escreverBundle(t, tt.segredoBundle),
+				VerifyKey: escreverChave(t, tt.segredoChave),
+			})
+			if tt.valido {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+		})
+	}
+}
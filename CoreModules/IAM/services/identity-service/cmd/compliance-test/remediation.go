@@ -209,9 +209,13 @@ func ApplyRemediations(ctx context.Context, testResults *ComplianceReport, confi
 		color.WhiteString("•"),
 		color.GreenString("%d", summary.SuccessfulRemediations))
 	
+	falhas := color.GreenString("%d", summary.FailedRemediations)
+	if summary.FailedRemediations > 0 {
+		falhas = color.RedString("%d", summary.FailedRemediations)
+	}
 	fmt.Printf("   %s Remediações falhas: %s\n", 
 		color.WhiteString("•"),
-		summary.FailedRemediations > 0 ? color.RedString("%d", summary.FailedRemediations) : color.GreenString("%d", summary.FailedRemediations))
+		falhas)
 
 	// Detalhes das políticas modificadas
 	if len(summary.PolicyFilesModified) > 0 && !config.DryRun {
@@ -3,29 +3,15 @@ package main
 import (
 	"strings"
 	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
 )
 
 // RemediationResult contém o resultado da aplicação de remediações
-type RemediationResult struct {
-	Enabled               bool                `json:"enabled"`
-	Success               bool                `json:"success"`
-	Timestamp             string              `json:"timestamp,omitempty"`
-	Message               string              `json:"message,omitempty"`
-	TotalViolations       int                 `json:"total_violations,omitempty"`
-	AttemptedRemediations int                 `json:"attempted_remediations,omitempty"`
-	SuccessfulRemediations int                `json:"successful_remediations,omitempty"`
-	FailedRemediations    int                 `json:"failed_remediations,omitempty"`
-	PolicyFilesModified   map[string][]string `json:"policy_files_modified,omitempty"`
-	Errors                []RemediationError  `json:"errors,omitempty"`
-	DryRun               bool                `json:"dry_run,omitempty"`
-}
+type RemediationResult = report.RemediationResult
 
 // RemediationError representa um erro durante a remediação
-type RemediationError struct {
-	PolicyFile   string `json:"policy_file"`
-	RuleID       string `json:"rule_id"`
-	ErrorMessage string `json:"error_message"`
-}
+type RemediationError = report.RemediationError
 
 // ComplianceReport representa o relatório completo de compliance para uma região
 type ComplianceReport struct {
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/replay"
+	"github.com/olekukonko/tablewriter"
+	"github.com/open-policy-agent/opa/rego"
+	"go.uber.org/zap"
+)
+
+// Código de saída do replay com --fail-on-change quando alguma decisão mudou
+const exitDecisoesAlteradas = 3
+
+// runReplay reavalia as entradas de um log de decisões contra uma nova versão das políticas
+// e retorna o código de saída do processo
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	logPath := fs.String("log", "", "Log de decisões NDJSON gravado com --decision-log")
+	opaPath := fs.String("opa", "./policies", "Caminho raiz da nova versão das políticas OPA")
+	bundlePath := fs.String("bundle", "", "Bundle OPA (diretório ou .tar.gz) com a nova versão das políticas")
+	bundleVerifyKey := fs.String("bundle-verify-key", "", "Arquivo com a chave pública ou segredo para verificar a assinatura do bundle")
+	bundleVerifyKeyID := fs.String("bundle-verify-key-id", "", "ID da chave de verificação (padrão: default)")
+	bundleVerifyAlg := fs.String("bundle-verify-alg", "", "Algoritmo de assinatura do bundle (padrão: RS256 para PEM, HS256 para segredos)")
+	bundleVerifyScope := fs.String("bundle-verify-scope", "", "Escopo esperado na assinatura do bundle")
+	bundleSkipVerify := fs.Bool("bundle-skip-verify", false, "Não verificar a assinatura do bundle")
+	dataStr := fs.String("data", "", "Documentos de dados e políticas auxiliares adicionais (separados por vírgula)")
+	reportPath := fs.String("report", "", "Arquivo JSON onde gravar o relatório de impacto, com as decisões alteradas")
+	failOnChange := fs.Bool("fail-on-change", false, "Terminar com código 3 quando alguma decisão mudar")
+	verbose := fs.Bool("verbose", false, "Modo verboso")
+	fs.Parse(args)
+
+	if *logPath == "" {
+		fmt.Fprintln(os.Stderr, "uso: compliance-test replay --log <decisões.ndjson> [--opa <políticas> | --bundle <bundle>] [--report <relatório.json>] [--fail-on-change]")
+		return 2
+	}
+
+	logger, err := setupLogger(*verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao configurar logger: %v\n", err)
+		return 1
+	}
+
+	config := Config{
+		OPAPath:           *opaPath,
+		BundlePath:        *bundlePath,
+		BundleVerifyKey:   *bundleVerifyKey,
+		BundleVerifyKeyID: *bundleVerifyKeyID,
+		BundleVerifyAlg:   *bundleVerifyAlg,
+		BundleVerifyScope: *bundleVerifyScope,
+		BundleSkipVerify:  *bundleSkipVerify,
+	}
+	if *dataStr != "" {
+		config.DataPaths = strings.Split(*dataStr, ",")
+	}
+
+	env, err := carregarAmbientePoliticas(logger, config)
+	if err != nil {
+		logger.Error("Erro ao preparar políticas OPA", zap.Error(err))
+		return 1
+	}
+
+	report, err := reavaliarDecisoes(logger, env, *logPath)
+	if err != nil {
+		logger.Error("Erro ao reavaliar log de decisões", zap.String("log", *logPath), zap.Error(err))
+		return 1
+	}
+
+	exibirImpactoReplay(report)
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			logger.Error("Erro ao gerar relatório de impacto", zap.Error(err))
+			return 1
+		}
+		if err := os.WriteFile(*reportPath, data, 0644); err != nil {
+			logger.Error("Erro ao salvar relatório de impacto", zap.Error(err))
+			return 1
+		}
+		logger.Info("Relatório de impacto gerado com sucesso", zap.String("path", *reportPath))
+	}
+
+	if *failOnChange && report.Changed > 0 {
+		return exitDecisoesAlteradas
+	}
+	return 0
+}
+
+// reavaliarDecisoes reavalia cada decisão do log contra as políticas atuais e agrega as alterações por política
+func reavaliarDecisoes(logger *zap.Logger, env *ambientePoliticas, logPath string) (*replay.Report, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir log de decisões: %w", err)
+	}
+	defer file.Close()
+
+	report, err := replay.Run(context.Background(), file, env.reavaliarDecisao)
+	if err != nil {
+		return nil, err
+	}
+
+	report.DecisionLog = logPath
+	if env.bundle != nil {
+		report.Revision = env.bundle.Manifest.Revision
+	}
+	for _, change := range report.Changes {
+		logger.Debug("Decisão alterada",
+			zap.String("testId", change.TestID),
+			zap.String("path", change.Path),
+			zap.String("impact", change.Impact))
+	}
+	return report, nil
+}
+
+// reavaliarDecisao avalia o input registado de uma decisão contra as políticas atuais
+func (env *ambientePoliticas) reavaliarDecisao(ctx context.Context, decisao *decisionlog.Decision) (interface{}, error) {
+	testCase := TestCase{
+		ID:         decisao.TestID,
+		PolicyPath: decisao.Path,
+		DataFiles:  decisao.DataFiles,
+	}
+	options := append([]func(*rego.Rego){rego.Query(decisao.Query)}, env.opcoesRego(testCase)...)
+
+	rs, err := rego.New(options...).Eval(ctx, rego.EvalInput(decisao.Input))
+	if err != nil {
+		return nil, err
+	}
+
+	var decision interface{}
+	if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+		decision = rs[0].Expressions[0].Value
+	}
+	return decision, nil
+}
+
+// exibirImpactoReplay exibe no terminal o raio de impacto por política e as decisões alteradas
+func exibirImpactoReplay(report *replay.Report) {
+	fmt.Println()
+	color.New(color.FgHiWhite, color.Bold).Println("=== IMPACTO DA ALTERAÇÃO DE POLÍTICAS ===")
+	fmt.Println()
+
+	fmt.Printf("Decisões reavaliadas: %d (", report.Total)
+	color.New(color.FgGreen).Printf("inalteradas: %d", report.Unchanged)
+	fmt.Print(", ")
+	color.New(color.FgYellow).Printf("alteradas: %d", report.Changed)
+	fmt.Println(")")
+	fmt.Print("Testes que deixam de passar: ")
+	color.New(color.FgRed).Printf("%d\n", report.Regressions)
+	fmt.Print("Testes que passam a passar: ")
+	color.New(color.FgGreen).Printf("%d\n\n", report.Fixed)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Política", "Decisões", "Alteradas", "Regressões", "Correções", "Impacto"})
+	table.SetBorder(false)
+	for _, policy := range report.Policies {
+		table.Append([]string{
+			policy.Path,
+			fmt.Sprintf("%d", policy.Decisions),
+			fmt.Sprintf("%d", policy.Changed),
+			fmt.Sprintf("%d", policy.Regressions),
+			fmt.Sprintf("%d", policy.Fixed),
+			fmt.Sprintf("%.2f%%", float64(policy.Changed)/float64(policy.Decisions)*100),
+		})
+	}
+	table.Render()
+
+	if len(report.Changes) == 0 {
+		return
+	}
+
+	color.New(color.FgHiYellow, color.Bold).Println("\nDecisões alteradas:")
+	for _, change := range report.Changes {
+		line := fmt.Sprintf("  • [%s] %s (%s): %s", change.Region, change.TestID, change.Path, change.Impact)
+		switch {
+		case change.PassedBefore && !change.PassedAfter:
+			color.Red("%s — deixa de passar", line)
+		case !change.PassedBefore && change.PassedAfter:
+			color.Green("%s — passa a passar", line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
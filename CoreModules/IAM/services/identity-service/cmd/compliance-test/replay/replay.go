@@ -0,0 +1,187 @@
+// Package replay reavalia um log de decisões OPA contra uma nova versão das políticas
+// e calcula o raio de impacto da alteração
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+)
+
+// Classificação de uma decisão reavaliada face à decisão registada
+const (
+	ImpactUnchanged       = "inalterada"
+	ImpactChanged         = "alterada"
+	ImpactNewError        = "erro_novo"
+	ImpactFixedError      = "erro_corrigido"
+	ImpactPersistentError = "erro_persistente"
+)
+
+// Change descreve uma decisão cujo resultado mudou com a nova versão das políticas
+type Change struct {
+	DecisionID   string      `json:"decisionId"`
+	Region       string      `json:"region"`
+	TestID       string      `json:"testId"`
+	Path         string      `json:"path"`
+	Impact       string      `json:"impact"`
+	Before       interface{} `json:"before"`
+	After        interface{} `json:"after"`
+	ErrorBefore  string      `json:"errorBefore,omitempty"`
+	ErrorAfter   string      `json:"errorAfter,omitempty"`
+	PassedBefore bool        `json:"passedBefore"`
+	PassedAfter  bool        `json:"passedAfter"`
+}
+
+// PolicyImpact agrega as decisões reavaliadas de uma política
+type PolicyImpact struct {
+	Path        string `json:"path"`
+	Decisions   int    `json:"decisions"`
+	Changed     int    `json:"changed"`
+	Regressions int    `json:"regressions"`
+	Fixed       int    `json:"fixed"`
+}
+
+// Report é o raio de impacto de uma alteração de políticas sobre um log de decisões
+type Report struct {
+	DecisionLog      string          `json:"decisionLog"`
+	RecordedRevision []string        `json:"recordedRevisions,omitempty"`
+	Revision         string          `json:"revision,omitempty"`
+	ExecutedAt       time.Time       `json:"executedAt"`
+	Duration         int64           `json:"durationMs"`
+	Total            int             `json:"total"`
+	Unchanged        int             `json:"unchanged"`
+	Changed          int             `json:"changed"`
+	Regressions      int             `json:"regressions"`
+	Fixed            int             `json:"fixed"`
+	Policies         []*PolicyImpact `json:"policies"`
+	Changes          []Change        `json:"changes"`
+}
+
+// Evaluator avalia o input de uma decisão registada contra a nova versão das políticas
+type Evaluator func(ctx context.Context, decisao *decisionlog.Decision) (interface{}, error)
+
+// Run reavalia cada decisão do log com o avaliador e agrega as alterações por política
+func Run(ctx context.Context, reader io.Reader, avaliar Evaluator) (*Report, error) {
+	report := &Report{ExecutedAt: time.Now()}
+	policies := make(map[string]*PolicyImpact)
+	startTime := time.Now()
+
+	err := decisionlog.Read(reader, func(decisao *decisionlog.Decision) error {
+		if decisao.BundleRevision != "" && !contains(report.RecordedRevision, decisao.BundleRevision) {
+			report.RecordedRevision = append(report.RecordedRevision, decisao.BundleRevision)
+		}
+
+		decision, evalErr := avaliar(ctx, decisao)
+		alteracao, err := comparar(decisao, decision, evalErr)
+		if err != nil {
+			return err
+		}
+
+		policy, ok := policies[decisao.Path]
+		if !ok {
+			policy = &PolicyImpact{Path: decisao.Path}
+			policies[decisao.Path] = policy
+		}
+		policy.Decisions++
+		report.Total++
+
+		if alteracao.Impact == ImpactUnchanged || alteracao.Impact == ImpactPersistentError {
+			report.Unchanged++
+			return nil
+		}
+
+		policy.Changed++
+		report.Changed++
+		if alteracao.PassedBefore && !alteracao.PassedAfter {
+			policy.Regressions++
+			report.Regressions++
+		} else if !alteracao.PassedBefore && alteracao.PassedAfter {
+			policy.Fixed++
+			report.Fixed++
+		}
+		report.Changes = append(report.Changes, alteracao)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Políticas com mais decisões alteradas primeiro
+	for _, policy := range policies {
+		report.Policies = append(report.Policies, policy)
+	}
+	sort.Slice(report.Policies, func(i, j int) bool {
+		if report.Policies[i].Changed != report.Policies[j].Changed {
+			return report.Policies[i].Changed > report.Policies[j].Changed
+		}
+		return report.Policies[i].Path < report.Policies[j].Path
+	})
+
+	report.Duration = time.Since(startTime).Milliseconds()
+	return report, nil
+}
+
+// comparar classifica a decisão reavaliada (ou o erro da reavaliação) face ao resultado registado
+func comparar(decisao *decisionlog.Decision, decision interface{}, evalErr error) (Change, error) {
+	alteracao := Change{
+		DecisionID:   decisao.DecisionID,
+		Region:       decisao.Region,
+		TestID:       decisao.TestID,
+		Path:         decisao.Path,
+		Before:       decisao.Result,
+		ErrorBefore:  decisao.Error,
+		PassedBefore: decisao.Passed,
+	}
+
+	if evalErr != nil {
+		alteracao.ErrorAfter = evalErr.Error()
+		if decisao.Error != "" {
+			alteracao.Impact = ImpactPersistentError
+		} else {
+			alteracao.Impact = ImpactNewError
+		}
+		return alteracao, nil
+	}
+	alteracao.After = decision
+
+	after, err := json.Marshal(decision)
+	if err != nil {
+		return alteracao, fmt.Errorf("erro ao serializar decisão do teste %s: %w", decisao.TestID, err)
+	}
+	expected, err := json.Marshal(decisao.Expected)
+	if err != nil {
+		return alteracao, fmt.Errorf("erro ao serializar decisão esperada do teste %s: %w", decisao.TestID, err)
+	}
+	alteracao.PassedAfter = string(after) == string(expected)
+
+	if decisao.Error != "" {
+		alteracao.Impact = ImpactFixedError
+		return alteracao, nil
+	}
+
+	before, err := json.Marshal(decisao.Result)
+	if err != nil {
+		return alteracao, fmt.Errorf("erro ao serializar decisão registada do teste %s: %w", decisao.TestID, err)
+	}
+	if string(before) == string(after) {
+		alteracao.Impact = ImpactUnchanged
+	} else {
+		alteracao.Impact = ImpactChanged
+	}
+	return alteracao, nil
+}
+
+// contains verifica se uma string está presente em uma slice
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	permitir = map[string]interface{}{"allow": true}
+	negar    = map[string]interface{}{"allow": false}
+)
+
+func TestComparar(t *testing.T) {
+	tests := []struct {
+		nome         string
+		registada    interface{}
+		erroAntes    string
+		esperada     interface{}
+		reavaliada   interface{}
+		erroDepois   error
+		impacto      string
+		passavaAntes bool
+		passaDepois  bool
+	}{
+		{"inalterada", negar, "", negar, negar, nil, ImpactUnchanged, true, true},
+		{"regressão", negar, "", negar, permitir, nil, ImpactChanged, true, false},
+		{"correção", permitir, "", negar, negar, nil, ImpactChanged, false, true},
+		{"alterada sem afetar o resultado do teste", permitir, "", negar, map[string]interface{}{"allow": "talvez"}, nil, ImpactChanged, false, false},
+		{"erro novo", negar, "", negar, nil, errors.New("conflito"), ImpactNewError, true, false},
+		{"erro persistente", nil, "undefined ref", negar, nil, errors.New("undefined ref"), ImpactPersistentError, false, false},
+		{"erro corrigido", nil, "undefined ref", negar, negar, nil, ImpactFixedError, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			decisao := &decisionlog.Decision{
+				DecisionID: "d1",
+				Region:     "AO",
+				TestID:     "ao-aml-001",
+				Path:       "aml/angola/pep",
+				Result:     tt.registada,
+				Error:      tt.erroAntes,
+				Expected:   tt.esperada,
+				Passed:     tt.passavaAntes,
+			}
+
+			alteracao, err := comparar(decisao, tt.reavaliada, tt.erroDepois)
+			require.NoError(t, err)
+			assert.Equal(t, tt.impacto, alteracao.Impact)
+			assert.Equal(t, tt.passavaAntes, alteracao.PassedBefore)
+			assert.Equal(t, tt.passaDepois, alteracao.PassedAfter)
+			assert.Equal(t, tt.registada, alteracao.Before)
+			assert.Equal(t, tt.erroAntes, alteracao.ErrorBefore)
+			assert.Equal(t, "d1", alteracao.DecisionID)
+			assert.Equal(t, "AO", alteracao.Region)
+			if tt.erroDepois != nil {
+				assert.Equal(t, tt.erroDepois.Error(), alteracao.ErrorAfter)
+				assert.Nil(t, alteracao.After)
+			} else {
+				assert.Equal(t, tt.reavaliada, alteracao.After)
+			}
+		})
+	}
+}
+
+func TestCompararNumbers(t *testing.T) {
+	// As decisões lidas do log usam json.Number e comparam-se pela serialização
+	decisao := &decisionlog.Decision{
+		Path:     "aml/angola/limite",
+		Result:   map[string]interface{}{"score": json.Number("10")},
+		Expected: map[string]interface{}{"score": json.Number("10")},
+		Passed:   true,
+	}
+
+	alteracao, err := comparar(decisao, map[string]interface{}{"score": json.Number("10")}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ImpactUnchanged, alteracao.Impact)
+	assert.True(t, alteracao.PassedAfter)
+}
+
+// logDecisoes tem duas políticas: pep com uma regressão e um erro persistente, consent com uma correção
+const logDecisoes = `{"decision_id":"d1","region":"AO","test_id":"ao-aml-001","path":"aml/pep","bundle_revision":"v1","input":{"id":1},"result":{"allow":false},"expected":{"allow":false},"passed":true}
+{"decision_id":"d2","region":"AO","test_id":"ao-aml-002","path":"aml/pep","bundle_revision":"v2","input":{"id":2},"result":{"allow":false},"expected":{"allow":false},"passed":true}
+{"decision_id":"d3","region":"AO","test_id":"ao-aml-003","path":"aml/pep","bundle_revision":"v1","input":{"id":3},"error":"undefined ref","expected":{"allow":false}}
+{"decision_id":"d4","region":"BR","test_id":"br-lgpd-001","path":"lgpd/consent","input":{"id":4},"result":{"allow":true},"expected":{"allow":false}}
+{"decision_id":"d5","region":"BR","test_id":"br-lgpd-002","path":"zz/outra","input":{"id":5},"result":{"allow":true},"expected":{"allow":true},"passed":true}
+`
+
+// avaliadorFalso simula a nova versão das políticas a partir do ID do input
+func avaliadorFalso(ctx context.Context, decisao *decisionlog.Decision) (interface{}, error) {
+	id := decisao.Input.(map[string]interface{})["id"].(json.Number).String()
+	switch id {
+	case "2":
+		return permitir, nil
+	case "3":
+		return nil, errors.New("undefined ref")
+	case "4":
+		return negar, nil
+	case "5":
+		return permitir, nil
+	}
+	return negar, nil
+}
+
+func TestRun(t *testing.T) {
+	var consultas []string
+	avaliar := func(ctx context.Context, decisao *decisionlog.Decision) (interface{}, error) {
+		consultas = append(consultas, decisao.Query)
+		return avaliadorFalso(ctx, decisao)
+	}
+
+	report, err := Run(context.Background(), strings.NewReader(logDecisoes), avaliar)
+	require.NoError(t, err)
+
+	assert.Equal(t, "data.aml.pep", consultas[0], "a consulta é derivada do caminho da política")
+	assert.Equal(t, []string{"v1", "v2"}, report.RecordedRevision, "revisões registadas sem repetições")
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 3, report.Unchanged, "erros persistentes contam como inalterados")
+	assert.Equal(t, 2, report.Changed)
+	assert.Equal(t, 1, report.Regressions)
+	assert.Equal(t, 1, report.Fixed)
+	assert.False(t, report.ExecutedAt.IsZero())
+
+	// Políticas com mais decisões alteradas primeiro e, em caso de empate, por caminho
+	require.Len(t, report.Policies, 3)
+	assert.Equal(t, PolicyImpact{Path: "aml/pep", Decisions: 3, Changed: 1, Regressions: 1}, *report.Policies[0])
+	assert.Equal(t, PolicyImpact{Path: "lgpd/consent", Decisions: 1, Changed: 1, Fixed: 1}, *report.Policies[1])
+	assert.Equal(t, PolicyImpact{Path: "zz/outra", Decisions: 1}, *report.Policies[2])
+
+	// Apenas as decisões alteradas entram no relatório, pela ordem do log
+	require.Len(t, report.Changes, 2)
+	assert.Equal(t, "ao-aml-002", report.Changes[0].TestID)
+	assert.True(t, report.Changes[0].PassedBefore)
+	assert.False(t, report.Changes[0].PassedAfter)
+	assert.Equal(t, "br-lgpd-001", report.Changes[1].TestID)
+	assert.Equal(t, "BR", report.Changes[1].Region)
+}
+
+func TestRunInvalidLog(t *testing.T) {
+	report, err := Run(context.Background(), strings.NewReader(`{"decision_id":"d1"}`), avaliadorFalso)
+	require.Error(t, err)
+	assert.Nil(t, report)
+	assert.Contains(t, err.Error(), "sem caminho de política")
+
+	report, err = Run(context.Background(), strings.NewReader(""), avaliadorFalso)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total)
+	assert.Empty(t, report.Changes)
+}
@@ -0,0 +1,348 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates/report.html.tmpl
+var reportTemplateFS embed.FS
+
+// reportTemplate é o modelo do relatório HTML autocontido (CSS, JS e dados embutidos)
+var reportTemplate = template.Must(template.New("report.html.tmpl").Funcs(template.FuncMap{
+	"scoreClass": classePontuacao,
+	"statusText": func(passed bool) string {
+		if passed {
+			return "Passou"
+		}
+		return "Falhou"
+	},
+	"criticalityClass": classeCriticidade,
+}).ParseFS(reportTemplateFS, "templates/report.html.tmpl"))
+
+// Sem referência a artigo: requisitos da matriz sem articleRefs
+const artigoSemReferencia = "Sem referência a artigo"
+
+var anchorInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// relatorioHTML é o modelo de dados do relatório HTML
+type relatorioHTML struct {
+	Summary     *TestSummary
+	GeneratedAt time.Time
+	Frameworks  []*frameworkHTML
+	Violations  []*violacaoHTML
+	History     []pontoHistorico
+}
+
+// frameworkHTML agrega os resultados de um framework por artigo
+type frameworkHTML struct {
+	Anchor      string
+	ID          string
+	Name        string
+	Version     string
+	Description string
+	References  []string
+	Score       FrameworkScore
+	Articles    []*artigoHTML
+}
+
+// artigoHTML agrega os requisitos e testes associados a um artigo do framework
+type artigoHTML struct {
+	Ref          string
+	TotalTests   int
+	PassedTests  int
+	Score        float64
+	Requirements []*requisitoHTML
+}
+
+// requisitoHTML lista os testes executados para um requisito
+type requisitoHTML struct {
+	ID          string
+	Name        string
+	Description string
+	Criticality string
+	Passed      bool
+	Tests       []*testeHTML
+}
+
+// testeHTML é uma linha da tabela de testes, com ligação para a página da violação
+type testeHTML struct {
+	Result          *TestResult
+	ViolationAnchor string
+}
+
+// violacaoHTML é a página de detalhe de um teste falhado
+type violacaoHTML struct {
+	Anchor       string
+	Result       *TestResult
+	Requirements []*requisitoHTML
+	Articles     []string
+	Expected     string
+	Actual       string
+	Input        string
+}
+
+// pontoHistorico é uma execução no gráfico de evolução da pontuação
+type pontoHistorico struct {
+	ExecutedAt time.Time          `json:"executedAt"`
+	Label      string             `json:"label"`
+	Score      float64            `json:"score"`
+	Passed     int                `json:"passed"`
+	Failed     int                `json:"failed"`
+	Frameworks map[string]float64 `json:"frameworks"`
+}
+
+// GenerateHTML produz o relatório HTML num único arquivo autocontido, adequado
+// para envio por email a auditores: visão geral com evolução da pontuação, páginas
+// por framework com detalhe por artigo e páginas de violação com o trace da decisão OPA
+func GenerateHTML(matrix *ComplianceMatrix, summary *TestSummary, history []*TestSummary) ([]byte, error) {
+	relatorio := &relatorioHTML{
+		Summary:     summary,
+		GeneratedAt: time.Now(),
+	}
+
+	violationAnchors := make(map[*TestResult]string)
+	for _, result := range summary.TestResults {
+		if !result.Passed {
+			violationAnchors[result] = "violacao-" + ancora(result.TestCase.ID)
+		}
+	}
+
+	requirements := make(map[string]*Requirement)
+	for i := range matrix.Requirements {
+		requirements[matrix.Requirements[i].ID] = &matrix.Requirements[i]
+	}
+
+	for _, framework := range matrix.Frameworks {
+		score, ok := summary.FrameworkScores[framework.ID]
+		if !ok {
+			continue
+		}
+		relatorio.Frameworks = append(relatorio.Frameworks, montarFramework(framework, score, matrix.Requirements, summary.TestResults, violationAnchors))
+	}
+
+	for _, result := range summary.TestResults {
+		if result.Passed {
+			continue
+		}
+		violation := &violacaoHTML{
+			Anchor:   violationAnchors[result],
+			Result:   result,
+			Expected: jsonIndentado(result.TestCase.ExpectedDecision),
+			Actual:   jsonIndentado(result.ActualDecision),
+			Input:    jsonIndentado(result.TestCase.Input),
+		}
+		for _, reqID := range result.Requirements {
+			req, ok := requirements[reqID]
+			if !ok {
+				continue
+			}
+			violation.Requirements = append(violation.Requirements, &requisitoHTML{
+				ID:          req.ID,
+				Name:        req.Name,
+				Description: req.Description,
+				Criticality: req.Criticality,
+			})
+			for _, ref := range req.ArticleRefs {
+				if !contains(violation.Articles, ref) {
+					violation.Articles = append(violation.Articles, ref)
+				}
+			}
+		}
+		relatorio.Violations = append(relatorio.Violations, violation)
+	}
+
+	for _, run := range append(history, summary) {
+		point := pontoHistorico{
+			ExecutedAt: run.ExecutedAt,
+			Label:      run.ExecutedAt.Format("02/01/2006 15:04"),
+			Score:      arredondar(run.ComplianceScore),
+			Passed:     run.PassedTests,
+			Failed:     run.FailedTests,
+			Frameworks: make(map[string]float64),
+		}
+		for id, fs := range run.FrameworkScores {
+			point.Frameworks[id] = arredondar(fs.ComplianceScore)
+		}
+		relatorio.History = append(relatorio.History, point)
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, relatorio); err != nil {
+		return nil, fmt.Errorf("erro ao gerar relatório HTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// montarFramework agrupa os testes do framework por artigo e requisito
+func montarFramework(framework Framework, score FrameworkScore, requirements []Requirement, results []*TestResult, violationAnchors map[*TestResult]string) *frameworkHTML {
+	view := &frameworkHTML{
+		Anchor:      "framework-" + ancora(framework.ID),
+		ID:          framework.ID,
+		Name:        framework.Name,
+		Version:     framework.Version,
+		Description: framework.Description,
+		References:  framework.References,
+		Score:       score,
+	}
+
+	articles := make(map[string]*artigoHTML)
+	articleTests := make(map[string]map[*TestResult]bool)
+	var order []string
+
+	for _, req := range requirements {
+		if req.FrameworkID != framework.ID {
+			continue
+		}
+
+		reqView := &requisitoHTML{
+			ID:          req.ID,
+			Name:        req.Name,
+			Description: req.Description,
+			Criticality: req.Criticality,
+			Passed:      true,
+		}
+		for _, result := range results {
+			if !contains(result.Requirements, req.ID) {
+				continue
+			}
+			reqView.Tests = append(reqView.Tests, &testeHTML{Result: result, ViolationAnchor: violationAnchors[result]})
+			if !result.Passed {
+				reqView.Passed = false
+			}
+		}
+		if len(reqView.Tests) == 0 {
+			continue
+		}
+
+		refs := req.ArticleRefs
+		if len(refs) == 0 {
+			refs = []string{artigoSemReferencia}
+		}
+		for _, ref := range refs {
+			article, ok := articles[ref]
+			if !ok {
+				article = &artigoHTML{Ref: ref}
+				articles[ref] = article
+				articleTests[ref] = make(map[*TestResult]bool)
+				order = append(order, ref)
+			}
+			article.Requirements = append(article.Requirements, reqView)
+
+			// Um teste associado a vários requisitos do mesmo artigo conta uma única vez
+			for _, test := range reqView.Tests {
+				if articleTests[ref][test.Result] {
+					continue
+				}
+				articleTests[ref][test.Result] = true
+				article.TotalTests++
+				if test.Result.Passed {
+					article.PassedTests++
+				}
+			}
+			article.Score = float64(article.PassedTests) / float64(article.TotalTests) * 100
+		}
+	}
+
+	// Artigos com falhas primeiro, depois pela ordem da matriz
+	sort.SliceStable(order, func(i, j int) bool {
+		return articles[order[i]].Score < articles[order[j]].Score
+	})
+	for _, ref := range order {
+		view.Articles = append(view.Articles, articles[ref])
+	}
+
+	return view
+}
+
+// LoadHistory lê os relatórios JSON anteriores da região, do mais antigo
+// para o mais recente, limitados às últimas limit execuções
+func LoadHistory(dir, region string, limit int) ([]*TestSummary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("compliance_report_%s_*.json", region)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relatórios anteriores: %w", err)
+	}
+
+	var history []*TestSummary
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler relatório anterior %s: %w", file, err)
+		}
+
+		var run TestSummary
+		if err := json.Unmarshal(data, &run); err != nil {
+			// Relatórios corrompidos ou de outro formato não impedem a geração do atual
+			continue
+		}
+		history = append(history, &run)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].ExecutedAt.Before(history[j].ExecutedAt)
+	})
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// classePontuacao retorna a classe CSS da pontuação de conformidade
+func classePontuacao(score float64) string {
+	if score < 70 {
+		return "failed"
+	} else if score < 90 {
+		return "warning"
+	}
+	return "passed"
+}
+
+// classeCriticidade retorna a classe CSS da criticidade do requisito
+func classeCriticidade(criticality string) string {
+	switch criticality {
+	case "alta":
+		return "criticality-alta"
+	case "média", "media":
+		return "criticality-media"
+	default:
+		return "criticality-baixa"
+	}
+}
+
+// ancora converte um identificador num id HTML válido
+func ancora(id string) string {
+	return strings.Trim(anchorInvalidChars.ReplaceAllString(id, "-"), "-")
+}
+
+// jsonIndentado formata um valor como JSON legível para as páginas de violação
+func jsonIndentado(value interface{}) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// arredondar limita a pontuação a duas casas decimais
+func arredondar(score float64) float64 {
+	return float64(int(score*100+0.5)) / 100
+}
+
+// contains verifica se um slice contém um determinado item
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
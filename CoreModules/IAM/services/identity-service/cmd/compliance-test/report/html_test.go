@@ -0,0 +1,219 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// matrizGDPR tem requisitos que partilham artigos, um requisito sem artigo e outro sem testes
+func matrizGDPR() *ComplianceMatrix {
+	return &ComplianceMatrix{
+		RegionCode: "EU",
+		RegionName: "União Europeia",
+		Frameworks: []Framework{
+			{ID: "GDPR", Name: "General Data Protection Regulation", Version: "2016/679"},
+			{ID: "ePrivacy", Name: "Diretiva ePrivacy"},
+		},
+		Requirements: []Requirement{
+			{ID: "gdpr-req-01", Name: "Minimização", FrameworkID: "GDPR", ArticleRefs: []string{"Art. 5", "Art. 32"}, Criticality: "alta"},
+			{ID: "gdpr-req-02", Name: "Segurança do tratamento", FrameworkID: "GDPR", ArticleRefs: []string{"Art. 32"}, Criticality: "média"},
+			{ID: "gdpr-req-03", Name: "Registo de atividades", FrameworkID: "GDPR", Criticality: "baixa"},
+			{ID: "gdpr-req-04", Name: "Dados sensíveis", FrameworkID: "GDPR", ArticleRefs: []string{"Art. 9"}},
+			{ID: "eprivacy-req-01", Name: "Cookies", FrameworkID: "ePrivacy", ArticleRefs: []string{"Art. 5(3)"}},
+		},
+	}
+}
+
+// sumarioGDPR tem um teste falhado associado a dois requisitos do mesmo artigo
+func sumarioGDPR(executedAt time.Time) *TestSummary {
+	results := []*TestResult{
+		{
+			TestCase:     TestCase{ID: "gdpr.t1", Name: "Acesso mínimo"},
+			Passed:       true,
+			Requirements: []string{"gdpr-req-01"},
+		},
+		{
+			TestCase: TestCase{
+				ID:               "gdpr.t2",
+				Name:             "Cifra em repouso",
+				Input:            map[string]interface{}{"storage": "plain"},
+				ExpectedDecision: map[string]interface{}{"allow": false},
+			},
+			ActualDecision: map[string]interface{}{"allow": true},
+			Requirements:   []string{"gdpr-req-01", "gdpr-req-02"},
+			Criticality:    "alta",
+			Violations:     []string{"armazenamento sem cifra"},
+			DecisionTrace:  "Enter data.gdpr.encryption.allow",
+		},
+		{
+			TestCase:     TestCase{ID: "gdpr.t3", Name: "Registo de acessos"},
+			Passed:       true,
+			Requirements: []string{"gdpr-req-02", "gdpr-req-03"},
+		},
+	}
+
+	return &TestSummary{
+		Region:          "EU",
+		RegionName:      "União Europeia",
+		TotalTests:      3,
+		PassedTests:     2,
+		FailedTests:     1,
+		ComplianceScore: 66.666,
+		FrameworkScores: map[string]FrameworkScore{
+			"GDPR": {ID: "GDPR", Name: "General Data Protection Regulation", TotalTests: 3, PassedTests: 2, FailedTests: 1, ComplianceScore: 66.666},
+		},
+		TestResults: results,
+		ExecutedAt:  executedAt,
+	}
+}
+
+func TestMontarFrameworkAggregatesByArticle(t *testing.T) {
+	matrix := matrizGDPR()
+	summary := sumarioGDPR(time.Now())
+	anchors := map[*TestResult]string{summary.TestResults[1]: "violacao-gdpr-t2"}
+
+	view := montarFramework(matrix.Frameworks[0], summary.FrameworkScores["GDPR"], matrix.Requirements, summary.TestResults, anchors)
+
+	assert.Equal(t, "framework-GDPR", view.Anchor)
+
+	// Artigos com falhas primeiro; o requisito sem testes (Art. 9) não aparece
+	var refs []string
+	for _, article := range view.Articles {
+		refs = append(refs, article.Ref)
+	}
+	require.Equal(t, []string{"Art. 5", "Art. 32", artigoSemReferencia}, refs)
+
+	tests := []struct {
+		ref          string
+		total        int
+		passed       int
+		score        float64
+		requirements int
+	}{
+		{"Art. 5", 2, 1, 50, 1},
+		// gdpr.t2 está associado a dois requisitos do Art. 32 e conta uma única vez
+		{"Art. 32", 3, 2, 200.0 / 3, 2},
+		{artigoSemReferencia, 1, 1, 100, 1},
+	}
+	for i, tt := range tests {
+		article := view.Articles[i]
+		assert.Equal(t, tt.total, article.TotalTests, tt.ref)
+		assert.Equal(t, tt.passed, article.PassedTests, tt.ref)
+		assert.InDelta(t, tt.score, article.Score, 0.001, tt.ref)
+		assert.Len(t, article.Requirements, tt.requirements, tt.ref)
+	}
+
+	// O requisito com um teste falhado fica reprovado e liga o teste à página da violação
+	req := view.Articles[0].Requirements[0]
+	assert.Equal(t, "gdpr-req-01", req.ID)
+	assert.False(t, req.Passed)
+	require.Len(t, req.Tests, 2)
+	assert.Empty(t, req.Tests[0].ViolationAnchor)
+	assert.Equal(t, "violacao-gdpr-t2", req.Tests[1].ViolationAnchor)
+	assert.True(t, view.Articles[2].Requirements[0].Passed)
+}
+
+func TestGenerateHTML(t *testing.T) {
+	executedAt := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	anterior := sumarioGDPR(executedAt.Add(-24 * time.Hour))
+	anterior.ComplianceScore = 33.333
+
+	data, err := GenerateHTML(matrizGDPR(), sumarioGDPR(executedAt), []*TestSummary{anterior})
+	require.NoError(t, err)
+	html := string(data)
+
+	// Página do framework com pontuação; frameworks sem pontuação na execução são omitidos
+	assert.Contains(t, html, `<section class="page" id="framework-GDPR">`)
+	assert.NotContains(t, html, `id="framework-ePrivacy"`)
+	assert.Contains(t, html, `<span class="failed">66.67%</span>`)
+
+	// Página da violação ligada a partir da tabela de testes e do índice
+	assert.Contains(t, html, `<a class="failed" href="#violacao-gdpr-t2">Falhou</a>`)
+	assert.Contains(t, html, `<section class="page" id="violacao-gdpr-t2">`)
+	assert.Contains(t, html, `<a href="#violacoes">Violações (1)</a>`)
+	assert.NotContains(t, html, `id="violacao-gdpr-t1"`)
+
+	// Artigos dos requisitos do teste, sem repetições, e decisão esperada, obtida e trace
+	assert.Contains(t, html, `<span class="summary-value">Art. 5, Art. 32</span>`)
+	assert.Contains(t, html, "<li>armazenamento sem cifra</li>")
+	assert.Contains(t, html, "&#34;allow&#34;: false")
+	assert.Contains(t, html, "&#34;allow&#34;: true")
+	assert.Contains(t, html, "&#34;storage&#34;: &#34;plain&#34;")
+	assert.Contains(t, html, `<pre class="trace">Enter data.gdpr.encryption.allow</pre>`)
+
+	// Evolução da pontuação: execuções anteriores seguidas da atual
+	assert.Contains(t, html, "<td>09/03/2026 09:30</td>")
+	assert.Contains(t, html, "<td>10/03/2026 09:30</td>")
+	assert.Contains(t, html, `"score":33.33`)
+	assert.Contains(t, html, `"frameworks":{"GDPR":66.67}`)
+}
+
+// gravarRelatorio grava um relatório JSON anterior no diretório de saída
+func gravarRelatorio(t *testing.T, dir, region string, summary *TestSummary) {
+	t.Helper()
+	data, err := json.Marshal(summary)
+	require.NoError(t, err)
+	arquivo := filepath.Join(dir, fmt.Sprintf("compliance_report_%s_%d.json", region, summary.ExecutedAt.Unix()))
+	require.NoError(t, os.WriteFile(arquivo, data, 0600))
+}
+
+func TestLoadHistory(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	// Gravados fora de ordem, para verificar a ordenação pela data de execução
+	for _, dia := range []int{3, 1, 4, 2} {
+		gravarRelatorio(t, dir, "AO", &TestSummary{Region: "AO", ExecutedAt: base.AddDate(0, 0, dia), ComplianceScore: float64(dia * 10)})
+	}
+	gravarRelatorio(t, dir, "BR", &TestSummary{Region: "BR", ExecutedAt: base.AddDate(0, 0, 5)})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compliance_report_AO_corrompido.json"), []byte("{"), 0600))
+
+	history, err := LoadHistory(dir, "AO", 0)
+	require.NoError(t, err)
+	var scores []float64
+	for _, run := range history {
+		assert.Equal(t, "AO", run.Region)
+		scores = append(scores, run.ComplianceScore)
+	}
+	assert.Equal(t, []float64{10, 20, 30, 40}, scores, "relatórios corrompidos e de outras regiões são ignorados")
+
+	history, err = LoadHistory(dir, "AO", 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, base.AddDate(0, 0, 3), history[0].ExecutedAt.UTC())
+	assert.Equal(t, base.AddDate(0, 0, 4), history[1].ExecutedAt.UTC())
+
+	history, err = LoadHistory(filepath.Join(dir, "inexistente"), "AO", 2)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestClassePontuacao(t *testing.T) {
+	tests := []struct {
+		score    float64
+		esperado string
+	}{
+		{0, "failed"},
+		{69.99, "failed"},
+		{70, "warning"},
+		{89.99, "warning"},
+		{90, "passed"},
+		{100, "passed"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.esperado, classePontuacao(tt.score), "%.2f", tt.score)
+	}
+}
+
+func TestAncora(t *testing.T) {
+	assert.Equal(t, "gdpr-t2", ancora("gdpr.t2"))
+	assert.Equal(t, "ao-aml_001", ancora(" ao/aml_001 "))
+	assert.Equal(t, "Art-5-3", ancora("Art. 5(3)"))
+}
@@ -0,0 +1,298 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Número máximo de testes listados por região, para o sumário caber num comentário de pull request
+const maxTestesMarkdown = 50
+
+// NewFailures retorna os IDs dos testes que falham na execução atual e passavam, ou não existiam,
+// na execução de referência. Sem referência não há novas falhas
+func NewFailures(summary, baseline *TestSummary) map[string]bool {
+	novas := make(map[string]bool)
+	if baseline == nil {
+		return novas
+	}
+
+	passavam := make(map[string]bool, len(baseline.TestResults))
+	for _, result := range baseline.TestResults {
+		passavam[result.TestCase.ID] = result.Passed
+	}
+	for _, result := range summary.TestResults {
+		if result.Passed {
+			continue
+		}
+		if passou, existia := passavam[result.TestCase.ID]; !existia || passou {
+			novas[result.TestCase.ID] = true
+		}
+	}
+	return novas
+}
+
+// corrigidos conta os testes que falhavam na execução de referência e passam na atual
+func corrigidos(summary, baseline *TestSummary) int {
+	if baseline == nil {
+		return 0
+	}
+
+	falhavam := make(map[string]bool)
+	for _, result := range baseline.TestResults {
+		if !result.Passed {
+			falhavam[result.TestCase.ID] = true
+		}
+	}
+	count := 0
+	for _, result := range summary.TestResults {
+		if result.Passed && falhavam[result.TestCase.ID] {
+			count++
+		}
+	}
+	return count
+}
+
+// Markdown gera o sumário compacto das regiões para comentários de pull request:
+// pontuação e delta por região e framework, novas falhas com ligação aos requisitos e falhas já existentes
+func Markdown(summaries []*TestSummary, baselines map[string]*TestSummary, requirementURL string) string {
+	var b strings.Builder
+
+	b.WriteString("## Testes de compliance\n\n")
+	b.WriteString("| Região | Pontuação | Δ | Aprovados | Reprovados | Novas falhas |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|\n")
+	for _, summary := range summaries {
+		baseline := baselines[summary.Region]
+		novas := "—"
+		delta := "—"
+		if baseline != nil {
+			novas = fmt.Sprintf("%d", len(NewFailures(summary, baseline)))
+			delta = formatarDelta(summary.ComplianceScore, baseline.ComplianceScore)
+		}
+		fmt.Fprintf(&b, "| %s %s | %.2f%% | %s | %d/%d | %d | %s |\n",
+			iconePontuacao(summary.ComplianceScore), escaparTexto(nomeRegiao(summary)),
+			summary.ComplianceScore, delta, summary.PassedTests, summary.TotalTests, summary.FailedTests, novas)
+	}
+
+	for _, summary := range summaries {
+		escreverRegiaoMarkdown(&b, summary, baselines[summary.Region], requirementURL)
+	}
+	return b.String()
+}
+
+// escreverRegiaoMarkdown escreve a secção de uma região: frameworks e testes reprovados
+func escreverRegiaoMarkdown(b *strings.Builder, summary, baseline *TestSummary, requirementURL string) {
+	fmt.Fprintf(b, "\n### %s\n\n", nomeRegiao(summary))
+	if baseline == nil {
+		b.WriteString("_Sem execução de referência: deltas e novas falhas indisponíveis._\n\n")
+	}
+
+	frameworkIDs := make([]string, 0, len(summary.FrameworkScores))
+	for id := range summary.FrameworkScores {
+		frameworkIDs = append(frameworkIDs, id)
+	}
+	sort.Strings(frameworkIDs)
+
+	if len(frameworkIDs) > 0 {
+		b.WriteString("| Framework | Pontuação | Δ | Aprovados |\n")
+		b.WriteString("|---|---:|---:|---:|\n")
+		for _, id := range frameworkIDs {
+			score := summary.FrameworkScores[id]
+			delta := "—"
+			if baseline != nil {
+				if anterior, ok := baseline.FrameworkScores[id]; ok {
+					delta = formatarDelta(score.ComplianceScore, anterior.ComplianceScore)
+				} else {
+					delta = "novo"
+				}
+			}
+			name := score.Name
+			if name == "" {
+				name = id
+			}
+			fmt.Fprintf(b, "| %s | %.2f%% | %s | %d/%d |\n",
+				escaparTexto(name), score.ComplianceScore, delta, score.PassedTests, score.TotalTests)
+		}
+		b.WriteString("\n")
+	}
+
+	var falhas []*TestResult
+	for _, result := range summary.TestResults {
+		if !result.Passed {
+			falhas = append(falhas, result)
+		}
+	}
+	sort.Slice(falhas, func(i, j int) bool { return falhas[i].TestCase.ID < falhas[j].TestCase.ID })
+
+	// Sem referência, todas as falhas são apresentadas como falhas da execução
+	if baseline == nil {
+		if len(falhas) > 0 {
+			fmt.Fprintf(b, "**Testes reprovados (%d)**\n\n", len(falhas))
+			escreverTestesMarkdown(b, summary.Region, falhas, requirementURL)
+		}
+		return
+	}
+
+	novas := NewFailures(summary, baseline)
+	var recentes, existentes []*TestResult
+	for _, result := range falhas {
+		if novas[result.TestCase.ID] {
+			recentes = append(recentes, result)
+		} else {
+			existentes = append(existentes, result)
+		}
+	}
+
+	if len(recentes) > 0 {
+		fmt.Fprintf(b, "**Novas falhas (%d)**\n\n", len(recentes))
+		escreverTestesMarkdown(b, summary.Region, recentes, requirementURL)
+	} else {
+		b.WriteString("Nenhuma nova falha em relação à execução de referência.\n\n")
+	}
+	if count := corrigidos(summary, baseline); count > 0 {
+		fmt.Fprintf(b, "Corrigidos desde a execução de referência: %d\n\n", count)
+	}
+	if len(existentes) > 0 {
+		fmt.Fprintf(b, "<details><summary>Falhas já existentes (%d)</summary>\n\n", len(existentes))
+		escreverTestesMarkdown(b, summary.Region, existentes, requirementURL)
+		b.WriteString("</details>\n\n")
+	}
+}
+
+// escreverTestesMarkdown lista os testes reprovados com os requisitos afetados, até maxTestesMarkdown
+func escreverTestesMarkdown(b *strings.Builder, region string, results []*TestResult, requirementURL string) {
+	for i, result := range results {
+		if i == maxTestesMarkdown {
+			fmt.Fprintf(b, "- … e mais %d\n", len(results)-maxTestesMarkdown)
+			break
+		}
+
+		fmt.Fprintf(b, "- `%s`", result.TestCase.ID)
+		if result.TestCase.Name != "" {
+			fmt.Fprintf(b, " %s", escaparTexto(result.TestCase.Name))
+		}
+		if requirements := requisitosResultado(result); len(requirements) > 0 {
+			links := make([]string, 0, len(requirements))
+			for _, reqID := range requirements {
+				links = append(links, linkRequisito(requirementURL, region, reqID))
+			}
+			fmt.Fprintf(b, " — %s", strings.Join(links, ", "))
+		}
+		if result.Criticality != "" {
+			fmt.Fprintf(b, " (criticidade %s)", result.Criticality)
+		}
+		if result.Message != "" {
+			fmt.Fprintf(b, "<br>%s", escaparTexto(result.Message))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// requisitosResultado retorna os requisitos do resultado, ou os declarados no caso de teste
+func requisitosResultado(result *TestResult) []string {
+	if len(result.Requirements) > 0 {
+		return result.Requirements
+	}
+	return result.TestCase.RequirementIDs
+}
+
+// linkRequisito formata o ID do requisito, com ligação quando --requirement-url é informado.
+// O modelo aceita os marcadores {id} e {region}
+func linkRequisito(template, region, reqID string) string {
+	if template == "" {
+		return "`" + reqID + "`"
+	}
+	link := strings.NewReplacer(
+		"{id}", url.PathEscape(reqID),
+		"{region}", url.PathEscape(region),
+	).Replace(template)
+	return fmt.Sprintf("[%s](%s)", reqID, link)
+}
+
+// formatarDelta formata a variação da pontuação em pontos percentuais
+func formatarDelta(atual, anterior float64) string {
+	delta := atual - anterior
+	switch {
+	case math.Abs(delta) < 0.005:
+		return "0.00"
+	case delta > 0:
+		return fmt.Sprintf("▲ +%.2f", delta)
+	default:
+		return fmt.Sprintf("▼ %.2f", delta)
+	}
+}
+
+// iconePontuacao retorna o indicador da pontuação, com os limites do relatório HTML
+func iconePontuacao(score float64) string {
+	switch classePontuacao(score) {
+	case "failed":
+		return "🔴"
+	case "warning":
+		return "🟡"
+	}
+	return "🟢"
+}
+
+// nomeRegiao retorna o nome da região com o código
+func nomeRegiao(summary *TestSummary) string {
+	if summary.RegionName == "" || summary.RegionName == summary.Region {
+		return summary.Region
+	}
+	return fmt.Sprintf("%s (%s)", summary.RegionName, summary.Region)
+}
+
+// escaparTexto evita que mensagens e nomes quebrem a lista ou a tabela Markdown
+func escaparTexto(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;", "|", "\\|").Replace(text)
+}
+
+// GitHubAnnotations escreve um comando de workflow do GitHub Actions (::error) por teste
+// reprovado, anotado no arquivo do caso de teste; as novas falhas são identificadas no título
+func GitHubAnnotations(w io.Writer, summary *TestSummary, novas map[string]bool) {
+	for _, result := range summary.TestResults {
+		if result.Passed {
+			continue
+		}
+
+		title := fmt.Sprintf("Compliance %s: %s", summary.Region, result.TestCase.ID)
+		if novas[result.TestCase.ID] {
+			title += " (nova falha)"
+		}
+		properties := []string{"title=" + escaparPropriedadeAnotacao(title)}
+		if result.TestCase.File != "" {
+			properties = append([]string{"file=" + escaparPropriedadeAnotacao(filepath.ToSlash(result.TestCase.File))}, properties...)
+		}
+
+		message := result.TestCase.Name
+		if message == "" {
+			message = result.TestCase.ID
+		}
+		if result.Message != "" {
+			message += ": " + result.Message
+		}
+		if requirements := requisitosResultado(result); len(requirements) > 0 {
+			message += "\nRequisitos: " + strings.Join(requirements, ", ")
+		}
+		if result.Criticality != "" {
+			message += "\nCriticidade: " + result.Criticality
+		}
+
+		fmt.Fprintf(w, "::error %s::%s\n", strings.Join(properties, ","), escaparDadosAnotacao(message))
+	}
+}
+
+// escaparDadosAnotacao escapa a mensagem de um comando de workflow
+func escaparDadosAnotacao(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+// escaparPropriedadeAnotacao escapa o valor de uma propriedade de um comando de workflow
+func escaparPropriedadeAnotacao(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
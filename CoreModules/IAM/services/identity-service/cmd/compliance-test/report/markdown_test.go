@@ -0,0 +1,171 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultado cria o resultado de um caso de teste com os requisitos indicados
+func resultado(id string, passed bool, requirements ...string) *TestResult {
+	return &TestResult{
+		TestCase:     TestCase{ID: id, Name: "Caso " + id},
+		Passed:       passed,
+		Requirements: requirements,
+	}
+}
+
+// sumarioAngola cria o sumário de Angola com uma pontuação do BNA
+func sumarioAngola(score float64, results ...*TestResult) *TestSummary {
+	summary := &TestSummary{
+		Region:          "AO",
+		RegionName:      "Angola",
+		ComplianceScore: score,
+		FrameworkScores: map[string]FrameworkScore{
+			"BNA": {ID: "BNA", Name: "Aviso BNA 02/2020", ComplianceScore: score},
+		},
+		TestResults: results,
+	}
+	for _, result := range results {
+		summary.TotalTests++
+		if result.Passed {
+			summary.PassedTests++
+		} else {
+			summary.FailedTests++
+		}
+	}
+	return summary
+}
+
+func TestNewFailures(t *testing.T) {
+	baseline := sumarioAngola(50,
+		resultado("ao-aml-001", true),
+		resultado("ao-aml-002", false),
+		resultado("ao-aml-003", false),
+	)
+	summary := sumarioAngola(50,
+		resultado("ao-aml-001", false), // passava
+		resultado("ao-aml-002", false), // já falhava
+		resultado("ao-aml-003", true),  // corrigido
+		resultado("ao-aml-004", false), // não existia
+	)
+
+	assert.Equal(t, map[string]bool{"ao-aml-001": true, "ao-aml-004": true}, NewFailures(summary, baseline))
+	assert.Empty(t, NewFailures(summary, nil), "sem referência não há novas falhas")
+
+	assert.Equal(t, 1, corrigidos(summary, baseline))
+	assert.Equal(t, 0, corrigidos(summary, nil))
+}
+
+func TestMarkdownWithBaseline(t *testing.T) {
+	baseline := sumarioAngola(75,
+		resultado("ao-aml-001", true, "ao-aml-req-01"),
+		resultado("ao-aml-002", false, "ao-aml-req-02"),
+		resultado("ao-aml-003", false),
+	)
+	baseline.FrameworkScores = map[string]FrameworkScore{"BNA": {ComplianceScore: 80}}
+
+	nova := resultado("ao-aml-001", false, "ao-aml-req-01", "ao req/02")
+	nova.Criticality = "alta"
+	nova.Message = "decisão <allow> | esperado deny"
+	summary := sumarioAngola(50,
+		nova,
+		resultado("ao-aml-002", false, "ao-aml-req-02"),
+		resultado("ao-aml-003", true),
+		resultado("ao-aml-004", true),
+	)
+	summary.FrameworkScores["FATF"] = FrameworkScore{ID: "FATF", ComplianceScore: 100, PassedTests: 1, TotalTests: 1}
+
+	md := Markdown([]*TestSummary{summary}, map[string]*TestSummary{"AO": baseline}, "https://compliance.innovabiz.ao/{region}/requisitos/{id}")
+
+	// Linha da região com pontuação, delta e número de novas falhas
+	assert.Contains(t, md, "| 🔴 Angola (AO) | 50.00% | ▼ -25.00 | 2/4 | 2 | 1 |\n")
+
+	// Frameworks ordenados por ID; frameworks ausentes na referência são marcados como novos
+	assert.Contains(t, md, "| Aviso BNA 02/2020 | 50.00% | ▼ -30.00 | 0/0 |\n")
+	assert.Contains(t, md, "| FATF | 100.00% | novo | 1/1 |\n")
+	assert.Less(t, strings.Index(md, "Aviso BNA"), strings.Index(md, "| FATF |"))
+
+	// Novas falhas com ligação aos requisitos e mensagem escapada
+	assert.Contains(t, md, "**Novas falhas (1)**\n\n"+
+		"- `ao-aml-001` Caso ao-aml-001 — "+
+		"[ao-aml-req-01](https://compliance.innovabiz.ao/AO/requisitos/ao-aml-req-01), "+
+		"[ao req/02](https://compliance.innovabiz.ao/AO/requisitos/ao%20req%2F02) "+
+		"(criticidade alta)<br>decisão &lt;allow&gt; \\| esperado deny\n")
+	assert.Contains(t, md, "Corrigidos desde a execução de referência: 1\n")
+	assert.Contains(t, md, "<details><summary>Falhas já existentes (1)</summary>\n\n- `ao-aml-002` Caso ao-aml-002 — [ao-aml-req-02]")
+	assert.NotContains(t, md, "Sem execução de referência")
+}
+
+func TestMarkdownWithoutBaseline(t *testing.T) {
+	falha := resultado("br-lgpd-001", false)
+	falha.TestCase.RequirementIDs = []string{"br-lgpd-req-07"}
+	summary := sumarioAngola(95, resultado("br-lgpd-002", true), falha)
+	summary.Region, summary.RegionName = "BR", "BR"
+
+	md := Markdown([]*TestSummary{summary}, nil, "")
+
+	assert.Contains(t, md, "| 🟢 BR | 95.00% | — | 1/2 | 1 | — |\n")
+	assert.Contains(t, md, "### BR\n\n_Sem execução de referência: deltas e novas falhas indisponíveis._")
+	assert.Contains(t, md, "| Aviso BNA 02/2020 | 95.00% | — | 0/0 |\n")
+
+	// Sem --requirement-url os requisitos do caso de teste aparecem sem ligação
+	assert.Contains(t, md, "**Testes reprovados (1)**\n\n- `br-lgpd-001` Caso br-lgpd-001 — `br-lgpd-req-07`\n")
+	assert.NotContains(t, md, "**Novas falhas")
+}
+
+func TestMarkdownTruncatesFailures(t *testing.T) {
+	var results []*TestResult
+	for i := 0; i < maxTestesMarkdown+2; i++ {
+		results = append(results, resultado(fmt.Sprintf("ao-pd-%03d", i), false))
+	}
+
+	md := Markdown([]*TestSummary{sumarioAngola(0, results...)}, nil, "")
+
+	assert.Equal(t, maxTestesMarkdown, strings.Count(md, "- `ao-pd-"))
+	assert.Contains(t, md, "- `ao-pd-049`")
+	assert.NotContains(t, md, "- `ao-pd-050`")
+	assert.Contains(t, md, "- … e mais 2\n")
+}
+
+func TestFormatarDelta(t *testing.T) {
+	tests := []struct {
+		atual, anterior float64
+		esperado        string
+	}{
+		{80, 80, "0.00"},
+		{80.004, 80, "0.00"},
+		{85.5, 80, "▲ +5.50"},
+		{70, 80.25, "▼ -10.25"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.esperado, formatarDelta(tt.atual, tt.anterior))
+	}
+}
+
+func TestGitHubAnnotations(t *testing.T) {
+	nova := resultado("ao-aml-001", false, "ao-aml-req-01", "ao-aml-req-02")
+	nova.TestCase.File = "test_cases/aml_kyc/ao-aml-001.json"
+	nova.Message = "100% dos alertas\nsem revisão"
+	nova.Criticality = "alta"
+	existente := resultado("ao-aml-002", false)
+	existente.TestCase.Name = ""
+
+	var out bytes.Buffer
+	GitHubAnnotations(&out, sumarioAngola(33, nova, existente, resultado("ao-aml-003", true)), map[string]bool{"ao-aml-001": true})
+
+	linhas := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, linhas, 2, "um comando por teste reprovado")
+	assert.Equal(t, "::error file=test_cases/aml_kyc/ao-aml-001.json,title=Compliance AO%3A ao-aml-001 (nova falha)"+
+		"::Caso ao-aml-001: 100%25 dos alertas%0Asem revisão%0ARequisitos: ao-aml-req-01, ao-aml-req-02%0ACriticidade: alta", linhas[0])
+	assert.Equal(t, "::error title=Compliance AO%3A ao-aml-002::ao-aml-002", linhas[1])
+}
+
+func TestEscaparPropriedadeAnotacao(t *testing.T) {
+	assert.Equal(t, "a%3Ab%2Cc%25d%0Ae%0D", escaparPropriedadeAnotacao("a:b,c%d\ne\r"))
+	assert.Equal(t, "a:b,c%25d%0Ae%0D", escaparDadosAnotacao("a:b,c%d\ne\r"))
+}
@@ -0,0 +1,126 @@
+// Package report define o modelo dos resultados de compliance e gera os relatórios
+// entregues a auditores e às pipelines de CI
+package report
+
+import "time"
+
+// ComplianceMatrix descreve os frameworks e requisitos regulatórios de uma região
+type ComplianceMatrix struct {
+	RegionCode    string        `json:"regionCode"`
+	RegionName    string        `json:"regionName"`
+	CrossRegional bool          `json:"crossRegional"`
+	Frameworks    []Framework   `json:"frameworks"`
+	Requirements  []Requirement `json:"requirements"`
+}
+
+// Framework é um referencial regulatório da matriz (ex: GDPR, LGPD, BNA)
+type Framework struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	References  []string `json:"references"`
+}
+
+// Requirement é um requisito do framework, associado aos artigos e casos de teste que o cobrem
+type Requirement struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	FrameworkID string   `json:"frameworkId"`
+	ArticleRefs []string `json:"articleRefs"`
+	Criticality string   `json:"criticality"`
+	TestCaseIDs []string `json:"testCaseIds"`
+}
+
+// TestCase é um caso de teste de uma política OPA
+type TestCase struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	RequirementIDs   []string          `json:"requirementIds"`
+	PolicyPath       string            `json:"policyPath"`
+	Input            interface{}       `json:"input"`
+	ExpectedDecision interface{}       `json:"expectedDecision"`
+	Tags             []string          `json:"tags"`
+	Context          map[string]string `json:"context"`
+	DataFiles        []string          `json:"dataFiles,omitempty"` // Documentos de dados adicionais (relativos a --opa)
+
+	Category string `json:"-"` // Subdiretório de test_cases onde o caso foi definido
+	File     string `json:"-"` // Arquivo JSON do caso de teste, usado nas anotações do GitHub Actions
+}
+
+// TestResult é o resultado da avaliação de um caso de teste
+type TestResult struct {
+	TestCase         TestCase    `json:"testCase"`
+	ActualDecision   interface{} `json:"actualDecision"`
+	Passed           bool        `json:"passed"`
+	Message          string      `json:"message,omitempty"`
+	ExecutionTimeMs  int64       `json:"executionTimeMs"`
+	PolicyPath       string      `json:"policyPath"`
+	Requirements     []string    `json:"requirements"`
+	Frameworks       []string    `json:"frameworks"`
+	Criticality      string      `json:"criticality"`
+	ComplianceRegion string      `json:"complianceRegion"`
+	ExecutedAt       time.Time   `json:"executedAt"`
+	Violations       []string    `json:"violations,omitempty"`
+	Tags             []string    `json:"tags"`
+	DecisionTrace    string      `json:"decisionTrace,omitempty"`
+
+	Metrics         map[string]interface{} `json:"-"` // Métricas OPA da avaliação, registadas no log de decisões
+	EvaluationError error                  `json:"-"` // Erro da avaliação da política, registado no log de decisões
+	PDPDecisionID   string                 `json:"-"` // ID da decisão no PDP remoto (--target pdp)
+}
+
+// TestSummary é o sumário da execução dos testes de uma região
+type TestSummary struct {
+	Region             string                    `json:"region"`
+	RegionName         string                    `json:"regionName"`
+	TotalTests         int                       `json:"totalTests"`
+	PassedTests        int                       `json:"passedTests"`
+	FailedTests        int                       `json:"failedTests"`
+	ComplianceScore    float64                   `json:"complianceScore"`
+	FrameworkScores    map[string]FrameworkScore `json:"frameworkScores"`
+	RequirementsMet    []string                  `json:"requirementsMet"`
+	RequirementsFailed []string                  `json:"requirementsFailed"`
+	TestResults        []*TestResult             `json:"testResults"`
+	ExecutedAt         time.Time                 `json:"executedAt"`
+	Duration           int64                     `json:"durationMs"`
+	RemediationApplied bool                      `json:"remediationApplied,omitempty"`
+	RemediationResult  *RemediationResult        `json:"remediationResult,omitempty"`
+	Target             string                    `json:"target,omitempty"`
+	PDPURL             string                    `json:"pdpUrl,omitempty"`
+	PDPRevisions       map[string]string         `json:"pdpRevisions,omitempty"`
+}
+
+// FrameworkScore é a pontuação de conformidade de um framework numa execução
+type FrameworkScore struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	TotalTests      int     `json:"totalTests"`
+	PassedTests     int     `json:"passedTests"`
+	FailedTests     int     `json:"failedTests"`
+	ComplianceScore float64 `json:"complianceScore"`
+}
+
+// RemediationResult contém o resultado da aplicação de remediações
+type RemediationResult struct {
+	Enabled                bool                `json:"enabled"`
+	Success                bool                `json:"success"`
+	Timestamp              string              `json:"timestamp,omitempty"`
+	Message                string              `json:"message,omitempty"`
+	TotalViolations        int                 `json:"total_violations,omitempty"`
+	AttemptedRemediations  int                 `json:"attempted_remediations,omitempty"`
+	SuccessfulRemediations int                 `json:"successful_remediations,omitempty"`
+	FailedRemediations     int                 `json:"failed_remediations,omitempty"`
+	PolicyFilesModified    map[string][]string `json:"policy_files_modified,omitempty"`
+	Errors                 []RemediationError  `json:"errors,omitempty"`
+	DryRun                 bool                `json:"dry_run,omitempty"`
+}
+
+// RemediationError representa um erro durante a remediação
+type RemediationError struct {
+	PolicyFile   string `json:"policy_file"`
+	RuleID       string `json:"rule_id"`
+	ErrorMessage string `json:"error_message"`
+}
@@ -0,0 +1,22 @@
+package main
+
+import "github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+
+const (
+	// Número padrão de execuções anteriores incluídas no gráfico de evolução
+	defaultHistoryRuns = 20
+
+	// Número máximo de linhas do trace OPA guardado em cada violação
+	maxTraceLines = 400
+)
+
+// gerarRelatorioHTML produz o relatório HTML autocontido enviado aos auditores
+func gerarRelatorioHTML(matrix *ComplianceMatrix, summary *TestSummary, history []*TestSummary) ([]byte, error) {
+	return report.GenerateHTML(matrix, summary, history)
+}
+
+// carregarHistoricoRelatorios lê os relatórios JSON anteriores da região, do mais antigo
+// para o mais recente, limitados às últimas limit execuções
+func carregarHistoricoRelatorios(dir, region string, limit int) ([]*TestSummary, error) {
+	return report.LoadHistory(dir, region, limit)
+}
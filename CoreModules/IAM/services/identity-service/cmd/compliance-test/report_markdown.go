@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+)
+
+const (
+	// Formato do sumário compacto para comentários de pull request (--report-format)
+	formatoMarkdown = "markdown"
+
+	// Nome do sumário Markdown em --output quando --report-output não é informado
+	sumarioMarkdownPadrao = "compliance_summary.md"
+)
+
+// validarSumarioPR verifica as opções do sumário Markdown e das anotações do GitHub Actions
+func validarSumarioPR(config Config) error {
+	switch config.SummaryFormat {
+	case "", formatoMarkdown:
+	default:
+		return fmt.Errorf("--report-format inválido: %s (suportado: %s)", config.SummaryFormat, formatoMarkdown)
+	}
+	if config.RequirementURL != "" && !strings.Contains(config.RequirementURL, "{id}") {
+		return fmt.Errorf("--requirement-url deve conter o marcador {id}")
+	}
+	return nil
+}
+
+// carregarBaselines carrega, por região, o relatório JSON mais recente usado como referência
+// para os deltas. Sem --baseline, a referência é a execução anterior gravada em --output,
+// pelo que tem de ser carregada antes de os testes gravarem o relatório atual
+func carregarBaselines(config Config) (map[string]*TestSummary, error) {
+	dir := config.BaselineDir
+	if dir == "" {
+		dir = config.OutputDir
+	}
+
+	baselines := make(map[string]*TestSummary)
+	for _, region := range config.Regions {
+		history, err := carregarHistoricoRelatorios(filepath.Join(dir, region), region, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(history) > 0 {
+			baselines[region] = history[0]
+		}
+	}
+	return baselines, nil
+}
+
+// novasFalhas retorna os IDs dos testes que falham na execução atual e passavam, ou não existiam,
+// na execução de referência
+func novasFalhas(summary, baseline *TestSummary) map[string]bool {
+	return report.NewFailures(summary, baseline)
+}
+
+// gerarSumarioMarkdown gera o sumário compacto das regiões para comentários de pull request
+func gerarSumarioMarkdown(summaries []*TestSummary, baselines map[string]*TestSummary, requirementURL string) string {
+	return report.Markdown(summaries, baselines, requirementURL)
+}
+
+// gravarSumarioMarkdown grava o sumário no arquivo informado; "-" escreve na saída padrão
+func gravarSumarioMarkdown(path, content string) error {
+	if path == "-" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório do sumário Markdown: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("erro ao gravar sumário Markdown: %w", err)
+	}
+	return nil
+}
+
+// emitirAnotacoesGitHub escreve um comando de workflow do GitHub Actions (::error) por teste reprovado
+func emitirAnotacoesGitHub(w io.Writer, summary *TestSummary, novas map[string]bool) {
+	report.GitHubAnnotations(w, summary, novas)
+}
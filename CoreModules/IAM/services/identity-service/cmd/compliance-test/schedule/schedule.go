@@ -0,0 +1,183 @@
+// Package schedule interpreta os agendamentos do modo serve: expressões cron de cinco campos,
+// atalhos (@daily) e intervalos fixos (@every 6h)
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Atalhos aceites no agendamento, equivalentes a expressões cron de cinco campos
+var atalhosAgendamento = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Limite da procura da próxima execução, para expressões que nunca ocorrem (ex: 30 de fevereiro)
+const horizonteAgendamento = 5 * 366 * 24 * time.Hour
+
+// campoCron descreve os valores admitidos por um campo da expressão cron
+type campoCron struct {
+	nome     string
+	min, max int
+}
+
+var camposCron = []campoCron{
+	{"minuto", 0, 59},
+	{"hora", 0, 23},
+	{"dia do mês", 1, 31},
+	{"mês", 1, 12},
+	{"dia da semana", 0, 7},
+}
+
+// Schedule determina os instantes das execuções do modo serve: uma expressão cron de cinco
+// campos (minuto hora dia-do-mês mês dia-da-semana), um atalho (@daily) ou um intervalo fixo (@every 6h)
+type Schedule struct {
+	expressao string
+	intervalo time.Duration
+
+	minutos, horas, diasMes, meses, diasSemana uint64
+	diaMesLivre, diaSemanaLivre                bool
+}
+
+// Parse interpreta a expressão de agendamento
+func Parse(expressao string) (*Schedule, error) {
+	expressao = strings.TrimSpace(expressao)
+	ag := &Schedule{expressao: expressao}
+
+	if resto, ok := strings.CutPrefix(expressao, "@every "); ok {
+		intervalo, err := time.ParseDuration(strings.TrimSpace(resto))
+		if err != nil {
+			return nil, fmt.Errorf("intervalo inválido em %q: %w", expressao, err)
+		}
+		if intervalo < time.Minute {
+			return nil, fmt.Errorf("intervalo inválido em %q: o mínimo é 1m", expressao)
+		}
+		ag.intervalo = intervalo
+		return ag, nil
+	}
+	if cron, ok := atalhosAgendamento[strings.ToLower(expressao)]; ok {
+		expressao = cron
+	}
+
+	campos := strings.Fields(expressao)
+	if len(campos) != len(camposCron) {
+		return nil, fmt.Errorf("agendamento %q deve ter %d campos (minuto hora dia mês dia-da-semana)", ag.expressao, len(camposCron))
+	}
+
+	valores := make([]uint64, len(campos))
+	for i, campo := range campos {
+		bits, err := analisarCampoCron(campo, camposCron[i])
+		if err != nil {
+			return nil, fmt.Errorf("agendamento %q: %w", ag.expressao, err)
+		}
+		valores[i] = bits
+	}
+	ag.minutos, ag.horas, ag.diasMes, ag.meses, ag.diasSemana = valores[0], valores[1], valores[2], valores[3], valores[4]
+
+	// Domingo pode ser indicado como 0 ou 7
+	if ag.diasSemana&(1<<7) != 0 {
+		ag.diasSemana |= 1
+	}
+	ag.diaMesLivre = strings.HasPrefix(campos[2], "*")
+	ag.diaSemanaLivre = strings.HasPrefix(campos[4], "*")
+	return ag, nil
+}
+
+// analisarCampoCron converte um campo (*, listas, intervalos a-b e passos /n) no conjunto de valores admitidos
+func analisarCampoCron(campo string, def campoCron) (uint64, error) {
+	var bits uint64
+	for _, parte := range strings.Split(campo, ",") {
+		faixa, passoStr, temPasso := strings.Cut(parte, "/")
+		passo := 1
+		if temPasso {
+			var err error
+			if passo, err = strconv.Atoi(passoStr); err != nil || passo <= 0 {
+				return 0, fmt.Errorf("passo inválido no %s: %q", def.nome, parte)
+			}
+		}
+
+		inicio, fim := def.min, def.max
+		switch {
+		case faixa == "*":
+		case strings.Contains(faixa, "-"):
+			a, b, _ := strings.Cut(faixa, "-")
+			var errA, errB error
+			inicio, errA = strconv.Atoi(a)
+			fim, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || inicio > fim {
+				return 0, fmt.Errorf("intervalo inválido no %s: %q", def.nome, parte)
+			}
+		default:
+			valor, err := strconv.Atoi(faixa)
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido no %s: %q", def.nome, parte)
+			}
+			inicio = valor
+			if !temPasso {
+				fim = valor
+			}
+		}
+		if inicio < def.min || fim > def.max {
+			return 0, fmt.Errorf("%s fora do intervalo %d-%d: %q", def.nome, def.min, def.max, parte)
+		}
+
+		for v := inicio; v <= fim; v += passo {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next retorna o primeiro instante do agendamento estritamente posterior a depois,
+// ou o instante zero quando a expressão não ocorre no horizonte de procura
+func (a *Schedule) Next(depois time.Time) time.Time {
+	if a.intervalo > 0 {
+		return depois.Add(a.intervalo)
+	}
+
+	t := depois.Truncate(time.Minute).Add(time.Minute)
+	limite := depois.Add(horizonteAgendamento)
+	for t.Before(limite) {
+		switch {
+		case a.meses&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !a.correspondeDia(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case a.horas&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case a.minutos&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// correspondeDia aplica a regra do cron: com dia do mês e dia da semana restritos,
+// basta que um deles corresponda
+func (a *Schedule) correspondeDia(t time.Time) bool {
+	diaMes := a.diasMes&(1<<uint(t.Day())) != 0
+	diaSemana := a.diasSemana&(1<<uint(t.Weekday())) != 0
+	switch {
+	case a.diaMesLivre && a.diaSemanaLivre:
+		return true
+	case a.diaMesLivre:
+		return diaSemana
+	case a.diaSemanaLivre:
+		return diaMes
+	default:
+		return diaMes || diaSemana
+	}
+}
+
+// String retorna a expressão original do agendamento
+func (a *Schedule) String() string {
+	return a.expressao
+}
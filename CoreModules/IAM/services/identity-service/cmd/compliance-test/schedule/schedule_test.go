@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instante cria um instante em UTC a partir de "2006-01-02 15:04:05"
+func instante(t *testing.T, valor string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02 15:04:05", valor)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		nome      string
+		expressao string
+		erro      string
+	}{
+		{"campos a menos", "0 */6 * *", "deve ter 5 campos"},
+		{"campos a mais", "0 0 * * * *", "deve ter 5 campos"},
+		{"vazio", "", "deve ter 5 campos"},
+		{"minuto fora do intervalo", "60 * * * *", "minuto fora do intervalo 0-59"},
+		{"dia do mês zero", "0 0 0 * *", "dia do mês fora do intervalo 1-31"},
+		{"dia da semana fora do intervalo", "0 0 * * 8", "dia da semana fora do intervalo 0-7"},
+		{"passo zero", "*/0 * * * *", "passo inválido no minuto"},
+		{"passo não numérico", "*/x * * * *", "passo inválido no minuto"},
+		{"intervalo invertido", "0 5-1 * * *", "intervalo inválido no hora"},
+		{"valor não numérico", "0 0 * jan *", "valor inválido no mês"},
+		{"atalho desconhecido", "@yearly", "deve ter 5 campos"},
+		{"intervalo abaixo do mínimo", "@every 30s", "o mínimo é 1m"},
+		{"intervalo inválido", "@every seis horas", "intervalo inválido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			ag, err := Parse(tt.expressao)
+			require.Error(t, err)
+			assert.Nil(t, ag)
+			assert.Contains(t, err.Error(), tt.erro)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		nome      string
+		expressao string
+		depois    string
+		esperado  string
+	}{
+		{"a cada seis horas", "0 */6 * * *", "2026-04-01 05:59:30", "2026-04-01 06:00:00"},
+		{"estritamente posterior", "0 */6 * * *", "2026-04-01 06:00:00", "2026-04-01 12:00:00"},
+		{"mudança de dia", "0 */6 * * *", "2026-04-01 18:00:01", "2026-04-02 00:00:00"},
+		{"lista de minutos", "15,45 * * * *", "2026-04-01 10:20:00", "2026-04-01 10:45:00"},
+		{"intervalo com passo", "0 8-18/5 * * *", "2026-04-01 13:10:00", "2026-04-01 18:00:00"},
+		{"valor com passo", "0 20/2 * * *", "2026-04-01 21:00:00", "2026-04-01 22:00:00"},
+		{"atalho diário", "@daily", "2026-04-01 10:00:00", "2026-04-02 00:00:00"},
+		{"atalho sem distinguir maiúsculas", "@HOURLY", "2026-04-01 10:00:00", "2026-04-01 11:00:00"},
+		{"atalho mensal", "@monthly", "2026-04-01 00:00:00", "2026-05-01 00:00:00"},
+		{"dias úteis após sexta-feira", "30 9 * * 1-5", "2026-04-03 10:00:00", "2026-04-06 09:30:00"},
+		{"domingo como 7", "0 0 * * 7", "2026-04-01 00:00:00", "2026-04-05 00:00:00"},
+		{"domingo como 0", "0 0 * * 0", "2026-04-01 00:00:00", "2026-04-05 00:00:00"},
+		{"dia do mês ou dia da semana", "0 0 3 * 0", "2026-04-01 00:00:00", "2026-04-03 00:00:00"},
+		{"dia da semana antes do dia do mês", "0 0 20 * 0", "2026-04-01 00:00:00", "2026-04-05 00:00:00"},
+		{"mês restrito", "0 6 1 12 *", "2026-04-01 00:00:00", "2026-12-01 06:00:00"},
+		{"29 de fevereiro", "0 0 29 2 *", "2026-04-01 00:00:00", "2028-02-29 00:00:00"},
+		{"intervalo fixo", "@every 6h30m", "2026-04-01 10:00:00", "2026-04-01 16:30:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			ag, err := Parse(tt.expressao)
+			require.NoError(t, err)
+			assert.Equal(t, instante(t, tt.esperado), ag.Next(instante(t, tt.depois)))
+			assert.Equal(t, tt.expressao, ag.String())
+		})
+	}
+}
+
+func TestNextNeverOccurs(t *testing.T) {
+	ag, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, ag.Next(instante(t, "2026-04-01 00:00:00")).IsZero(), "30 de fevereiro nunca ocorre")
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	luanda := time.FixedZone("WAT", 3600)
+	ag, err := Parse("0 8 * * *")
+	require.NoError(t, err)
+
+	proxima := ag.Next(time.Date(2026, 4, 1, 9, 0, 0, 0, luanda))
+	assert.Equal(t, time.Date(2026, 4, 2, 8, 0, 0, 0, luanda), proxima)
+	assert.Equal(t, luanda, proxima.Location())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/scorecard"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// Valores padrão do subcomando scorecard
+const (
+	violacoesRecorrentesPadrao = 10
+	tempoLimiteScorecard       = 30 * time.Second
+)
+
+// Formatos de saída do scorecard
+const (
+	formatoScorecardTabela   = "table"
+	formatoScorecardMarkdown = "markdown"
+	formatoScorecardJSON     = "json"
+)
+
+// runScorecard gera o scorecard de compliance a partir do histórico gravado com --history-db ou
+// pelo modo serve e retorna o código de saída do processo
+func runScorecard(args []string) int {
+	fs := flag.NewFlagSet("scorecard", flag.ExitOnError)
+	historyDB := fs.String("history-db", os.Getenv("COMPLIANCE_HISTORY_DB"), "Histórico das execuções: arquivo SQLite ou URL postgres:// (padrão: variável COMPLIANCE_HISTORY_DB)")
+	regionStr := fs.String("regions", "", "Regiões a incluir (separadas por vírgula; padrão: todas as regiões do histórico)")
+	frameworkStr := fs.String("frameworks", "", "Frameworks a incluir (separados por vírgula)")
+	runs := fs.Int("runs", execucoesTendencia, "Número de execuções mais recentes de cada região")
+	top := fs.Int("top", violacoesRecorrentesPadrao, "Número de violações recorrentes a listar")
+	format := fs.String("format", formatoScorecardTabela, "Formato do scorecard (table, markdown, json)")
+	output := fs.String("output", "", "Arquivo onde gravar o scorecard (padrão: saída padrão)")
+	verbose := fs.Bool("verbose", false, "Modo verboso")
+	fs.Parse(args)
+
+	if *historyDB == "" {
+		fmt.Fprintln(os.Stderr, "uso: compliance-test scorecard --history-db <histórico.db | postgres://...> [--regions AO,BR] [--frameworks GDPR] [--runs 10] [--format table|markdown|json] [--output <arquivo>]")
+		return 2
+	}
+
+	logger, err := setupLogger(*verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao configurar logger: %v\n", err)
+		return 1
+	}
+
+	switch *format {
+	case formatoScorecardTabela, formatoScorecardMarkdown, formatoScorecardJSON:
+	default:
+		logger.Error("Formato de scorecard inválido", zap.String("format", *format))
+		return 2
+	}
+	if *runs < 1 || *top < 0 {
+		logger.Error("Opções de scorecard inválidas", zap.Error(fmt.Errorf("--runs deve ser positivo e --top não pode ser negativo")))
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tempoLimiteScorecard)
+	defer cancel()
+
+	registo, err := abrirRegistoTendencias(ctx, *historyDB)
+	if err != nil {
+		logger.Error("Erro ao abrir o histórico de execuções", zap.Error(err))
+		return 1
+	}
+	defer registo.Close()
+
+	var regioes []string
+	if *regionStr != "" {
+		regioes = strings.Split(*regionStr, ",")
+	} else if regioes, err = registo.Regions(ctx); err != nil {
+		logger.Error("Erro ao consultar o histórico de execuções", zap.Error(err))
+		return 1
+	}
+
+	historicos := make(map[string][]history.Run, len(regioes))
+	for _, region := range regioes {
+		historico, err := registo.History(ctx, region, *runs)
+		if err != nil {
+			logger.Error("Erro ao consultar o histórico de execuções", zap.String("region", region), zap.Error(err))
+			return 1
+		}
+		if len(historico) == 0 {
+			logger.Warn("Região sem execuções no histórico", zap.String("region", region))
+			continue
+		}
+		historicos[region] = historico
+	}
+	if len(historicos) == 0 {
+		logger.Error("O histórico não tem execuções para gerar o scorecard", zap.String("history_db", *historyDB))
+		return 1
+	}
+
+	var frameworks []string
+	if *frameworkStr != "" {
+		frameworks = strings.Split(*frameworkStr, ",")
+	}
+	card := scorecard.Calculate(historicos, frameworks, *runs, *top)
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			logger.Error("Erro ao criar arquivo do scorecard", zap.String("path", *output), zap.Error(err))
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case formatoScorecardJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(card)
+	case formatoScorecardMarkdown:
+		err = scorecard.WriteMarkdown(w, card)
+	default:
+		escreverScorecardTabela(w, card)
+	}
+	if err != nil {
+		logger.Error("Erro ao gravar o scorecard", zap.Error(err))
+		return 1
+	}
+	if *output != "" {
+		logger.Info("Scorecard gerado com sucesso", zap.String("path", *output))
+	}
+	return 0
+}
+
+// escreverScorecardTabela apresenta o scorecard em tabelas de texto
+func escreverScorecardTabela(w io.Writer, card *scorecard.Scorecard) {
+	tendencias, violacoes, remediacao := scorecard.Rows(card)
+	secoes := []struct {
+		titulo    string
+		cabecalho []string
+		linhas    [][]string
+	}{
+		{fmt.Sprintf("Tendência de compliance (últimas %d execuções)", card.Window), scorecard.TrendHeader, tendencias},
+		{"Violações recorrentes", scorecard.ViolationsHeader, violacoes},
+		{"Tempo médio de remediação", scorecard.RemediationHeader, remediacao},
+	}
+	for _, secao := range secoes {
+		fmt.Fprintf(w, "\n%s\n\n", secao.titulo)
+		if len(secao.linhas) == 0 {
+			fmt.Fprintln(w, "Nenhuma violação recorrente na janela.")
+			continue
+		}
+		table := tablewriter.NewWriter(w)
+		table.SetHeader(secao.cabecalho)
+		table.SetBorder(false)
+		table.AppendBulk(secao.linhas)
+		table.Render()
+	}
+}
@@ -0,0 +1,472 @@
+// Package scorecard gera o scorecard de governação a partir do histórico de execuções: tendências
+// por região e framework, violações recorrentes e tempo médio de remediação
+package scorecard
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+)
+
+// Níveis da sparkline das pontuações, numa escala absoluta de 0 a 100
+var niveisSparkline = []rune("▁▂▃▄▅▆▇█")
+
+// Point é a pontuação de uma execução na série de uma região ou framework
+type Point struct {
+	RunID      string    `json:"runId"`
+	ExecutedAt time.Time `json:"executedAt"`
+	Score      float64   `json:"score"`
+}
+
+// Framework é a evolução de um framework de uma região ao longo da janela
+type Framework struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Score   float64 `json:"score"`
+	Delta   float64 `json:"delta"`
+	Average float64 `json:"average"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Change  float64 `json:"change"` // Variação entre a primeira e a última execução da janela
+	Series  []Point `json:"series"`
+}
+
+// Remediation resume os episódios de falha dos casos de teste: um episódio começa na
+// primeira execução em que o teste reprova e termina na primeira execução seguinte em que passa
+type Remediation struct {
+	Remediated int     `json:"remediated"`
+	Open       int     `json:"open"`
+	MeanHours  float64 `json:"meanHours"` // Tempo médio até à remediação (MTTR)
+	MeanRuns   float64 `json:"meanRuns"`  // Número médio de execuções até à remediação
+}
+
+// Region é a evolução de compliance de uma região nas últimas execuções
+type Region struct {
+	Region      string      `json:"region"`
+	RegionName  string      `json:"regionName,omitempty"`
+	Runs        int         `json:"runs"`
+	Score       float64     `json:"score"`
+	Delta       float64     `json:"delta"`
+	Average     float64     `json:"average"`
+	Slope       float64     `json:"slopePerRun"`
+	Min         float64     `json:"min"`
+	Max         float64     `json:"max"`
+	Change      float64     `json:"change"`
+	Series      []Point     `json:"series"`
+	Frameworks  []Framework `json:"frameworks"`
+	Remediation Remediation `json:"remediation"`
+}
+
+// Violation é um caso de teste que reprovou em várias execuções da janela
+type Violation struct {
+	TestID       string    `json:"testId"`
+	Name         string    `json:"name"`
+	Criticality  string    `json:"criticality,omitempty"`
+	Requirements []string  `json:"requirements,omitempty"`
+	Frameworks   []string  `json:"frameworks,omitempty"`
+	Regions      []string  `json:"regions"`
+	Failures     int       `json:"failures"` // Execuções em que o teste reprovou
+	Runs         int       `json:"runs"`     // Execuções das regiões onde o teste reprovou
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+	Open         bool      `json:"open"` // Reprovado na última execução de alguma região
+	Violation    string    `json:"violation,omitempty"`
+}
+
+// Scorecard é o relatório de governação gerado a partir do histórico de execuções
+type Scorecard struct {
+	GeneratedAt   time.Time   `json:"generatedAt"`
+	Window        int         `json:"window"`
+	Frameworks    []string    `json:"frameworks,omitempty"`
+	Regions       []Region    `json:"regions"`
+	TopViolations []Violation `json:"topViolations"`
+	Remediation   Remediation `json:"remediation"`
+}
+
+// Calculate calcula as tendências, as violações recorrentes e o tempo médio de remediação
+// a partir dos históricos das regiões, ordenados da execução mais recente para a mais antiga
+// Com frameworks, apenas esses frameworks e os testes reprovados que os cobrem são considerados
+func Calculate(historicos map[string][]history.Run, frameworks []string, janela, top int) *Scorecard {
+	filtro := make(map[string]bool, len(frameworks))
+	for _, id := range frameworks {
+		filtro[strings.TrimSpace(id)] = true
+	}
+	incluirFramework := func(ids ...string) bool {
+		if len(filtro) == 0 {
+			return true
+		}
+		for _, id := range ids {
+			if filtro[id] {
+				return true
+			}
+		}
+		return false
+	}
+
+	card := &Scorecard{
+		GeneratedAt:   time.Now().UTC(),
+		Window:        janela,
+		Frameworks:    frameworks,
+		TopViolations: []Violation{},
+	}
+
+	regioes := make([]string, 0, len(historicos))
+	for region := range historicos {
+		regioes = append(regioes, region)
+	}
+	sort.Strings(regioes)
+
+	recorrentes := make(map[string]*Violation)
+	mensagens := make(map[string]map[string]int)
+	var duracoes []time.Duration
+	var execucoesAteRemediar []int
+	var abertos int
+
+	for _, region := range regioes {
+		historico := historicos[region]
+		tendencia := history.CalculateTrend(historico)
+		cronologico := make([]history.Run, len(historico))
+		for i, execucao := range historico {
+			cronologico[len(historico)-1-i] = execucao
+		}
+
+		linha := Region{
+			Region:     region,
+			RegionName: historico[0].RegionName,
+			Runs:       tendencia.Runs,
+			Score:      tendencia.Score,
+			Delta:      tendencia.Delta,
+			Average:    tendencia.Average,
+			Slope:      tendencia.Slope,
+			Frameworks: []Framework{},
+		}
+		for _, execucao := range cronologico {
+			linha.Series = append(linha.Series, Point{RunID: execucao.RunID, ExecutedAt: execucao.ExecutedAt, Score: execucao.ComplianceScore})
+		}
+		linha.Min, linha.Max, linha.Change = extremosSerie(linha.Series)
+
+		for id, framework := range tendencia.Frameworks {
+			if !incluirFramework(id) {
+				continue
+			}
+			serie := Framework{ID: id, Name: framework.Name, Score: framework.Score, Delta: framework.Delta, Average: framework.Average}
+			for _, execucao := range cronologico {
+				score, ok := execucao.FrameworkScores[id]
+				if !ok || score.TotalTests == 0 {
+					continue
+				}
+				serie.Series = append(serie.Series, Point{RunID: execucao.RunID, ExecutedAt: execucao.ExecutedAt, Score: score.ComplianceScore})
+			}
+			serie.Min, serie.Max, serie.Change = extremosSerie(serie.Series)
+			linha.Frameworks = append(linha.Frameworks, serie)
+		}
+		sort.Slice(linha.Frameworks, func(i, j int) bool { return linha.Frameworks[i].ID < linha.Frameworks[j].ID })
+
+		// Episódios de falha por caso de teste, percorrendo as execuções da mais antiga para a mais recente
+		inicios := make(map[string]int)
+		var duracoesRegiao []time.Duration
+		var execucoesRegiao []int
+		for i, execucao := range cronologico {
+			reprovados := make(map[string]bool, len(execucao.Failures))
+			for _, falha := range execucao.Failures {
+				if !incluirFramework(falha.Frameworks...) {
+					continue
+				}
+				reprovados[falha.TestID] = true
+				if _, aberto := inicios[falha.TestID]; !aberto {
+					inicios[falha.TestID] = i
+				}
+
+				recorrente, ok := recorrentes[falha.TestID]
+				if !ok {
+					recorrente = &Violation{
+						TestID:       falha.TestID,
+						Name:         falha.Name,
+						Criticality:  falha.Criticality,
+						Requirements: falha.Requirements,
+						Frameworks:   falha.Frameworks,
+						FirstSeen:    execucao.ExecutedAt,
+					}
+					recorrentes[falha.TestID] = recorrente
+					mensagens[falha.TestID] = make(map[string]int)
+				}
+				if !contem(recorrente.Regions, region) {
+					recorrente.Regions = append(recorrente.Regions, region)
+					recorrente.Runs += len(cronologico)
+				}
+				recorrente.Failures++
+				if execucao.ExecutedAt.Before(recorrente.FirstSeen) {
+					recorrente.FirstSeen = execucao.ExecutedAt
+				}
+				if execucao.ExecutedAt.After(recorrente.LastSeen) {
+					recorrente.LastSeen = execucao.ExecutedAt
+				}
+				if i == len(cronologico)-1 {
+					recorrente.Open = true
+				}
+				for _, violacao := range falha.Violations {
+					mensagens[falha.TestID][violacao]++
+				}
+			}
+
+			for testID, inicio := range inicios {
+				if reprovados[testID] {
+					continue
+				}
+				duracoesRegiao = append(duracoesRegiao, execucao.ExecutedAt.Sub(cronologico[inicio].ExecutedAt))
+				execucoesRegiao = append(execucoesRegiao, i-inicio)
+				delete(inicios, testID)
+			}
+		}
+		linha.Remediation = resumirRemediacao(duracoesRegiao, execucoesRegiao, len(inicios))
+		duracoes = append(duracoes, duracoesRegiao...)
+		execucoesAteRemediar = append(execucoesAteRemediar, execucoesRegiao...)
+		abertos += len(inicios)
+
+		card.Regions = append(card.Regions, linha)
+	}
+	card.Remediation = resumirRemediacao(duracoes, execucoesAteRemediar, abertos)
+
+	for testID, recorrente := range recorrentes {
+		sort.Strings(recorrente.Regions)
+		recorrente.Violation = mensagemMaisFrequente(mensagens[testID])
+		card.TopViolations = append(card.TopViolations, *recorrente)
+	}
+	sort.Slice(card.TopViolations, func(i, j int) bool {
+		a, b := card.TopViolations[i], card.TopViolations[j]
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		if a.Open != b.Open {
+			return a.Open
+		}
+		return a.TestID < b.TestID
+	})
+	if len(card.TopViolations) > top {
+		card.TopViolations = card.TopViolations[:top]
+	}
+	return card
+}
+
+// extremosSerie retorna o mínimo, o máximo e a variação entre o primeiro e o último ponto da série
+func extremosSerie(serie []Point) (minimo, maximo, variacao float64) {
+	if len(serie) == 0 {
+		return 0, 0, 0
+	}
+	minimo, maximo = serie[0].Score, serie[0].Score
+	for _, ponto := range serie[1:] {
+		if ponto.Score < minimo {
+			minimo = ponto.Score
+		}
+		if ponto.Score > maximo {
+			maximo = ponto.Score
+		}
+	}
+	return minimo, maximo, serie[len(serie)-1].Score - serie[0].Score
+}
+
+// resumirRemediacao calcula as médias dos episódios de falha remediados
+func resumirRemediacao(duracoes []time.Duration, execucoes []int, abertos int) Remediation {
+	resumo := Remediation{Remediated: len(duracoes), Open: abertos}
+	if len(duracoes) == 0 {
+		return resumo
+	}
+	horas := make([]float64, len(duracoes))
+	for i, d := range duracoes {
+		horas[i] = d.Hours()
+	}
+	numeros := make([]float64, len(execucoes))
+	for i, n := range execucoes {
+		numeros[i] = float64(n)
+	}
+	resumo.MeanHours = history.Mean(horas)
+	resumo.MeanRuns = history.Mean(numeros)
+	return resumo
+}
+
+// mensagemMaisFrequente retorna a violação mais frequente; os empates são resolvidos por ordem alfabética
+func mensagemMaisFrequente(contagens map[string]int) string {
+	var melhor string
+	for mensagem, n := range contagens {
+		if n > contagens[melhor] || (n == contagens[melhor] && mensagem < melhor) {
+			melhor = mensagem
+		}
+	}
+	return melhor
+}
+
+// contem indica se o valor está na lista
+func contem(valores []string, valor string) bool {
+	for _, v := range valores {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
+
+// sparkline representa a série de pontuações numa escala absoluta de 0 a 100
+func sparkline(serie []Point) string {
+	var b strings.Builder
+	for _, ponto := range serie {
+		nivel := int(ponto.Score / 100 * float64(len(niveisSparkline)-1))
+		if nivel < 0 {
+			nivel = 0
+		}
+		if nivel >= len(niveisSparkline) {
+			nivel = len(niveisSparkline) - 1
+		}
+		b.WriteRune(niveisSparkline[nivel])
+	}
+	return b.String()
+}
+
+// formatarMTTR apresenta o tempo médio de remediação em dias e horas
+func formatarMTTR(resumo Remediation) string {
+	if resumo.Remediated == 0 {
+		return "-"
+	}
+	d := time.Duration(resumo.MeanHours * float64(time.Hour)).Round(time.Minute)
+	dias := int(d.Hours()) / 24
+	horas := int(d.Hours()) % 24
+	switch {
+	case dias > 0:
+		return fmt.Sprintf("%dd %dh", dias, horas)
+	case horas > 0:
+		return fmt.Sprintf("%dh %dm", horas, int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// formatarExecucoesRemediacao apresenta o número médio de execuções até à remediação
+func formatarExecucoesRemediacao(resumo Remediation) string {
+	if resumo.Remediated == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", resumo.MeanRuns)
+}
+
+// Rows retorna as linhas das tabelas do scorecard, partilhadas pelos formatos table e markdown
+func Rows(card *Scorecard) (tendencias, violacoes, remediacao [][]string) {
+	for _, regiao := range card.Regions {
+		tendencias = append(tendencias, []string{
+			regiao.Region,
+			"Global",
+			fmt.Sprintf("%.1f%%", regiao.Score),
+			fmt.Sprintf("%+.1f", regiao.Delta),
+			fmt.Sprintf("%+.1f", regiao.Change),
+			fmt.Sprintf("%.1f%% – %.1f%%", regiao.Min, regiao.Max),
+			fmt.Sprintf("%d", regiao.Runs),
+			sparkline(regiao.Series),
+		})
+		for _, framework := range regiao.Frameworks {
+			tendencias = append(tendencias, []string{
+				regiao.Region,
+				framework.ID,
+				fmt.Sprintf("%.1f%%", framework.Score),
+				fmt.Sprintf("%+.1f", framework.Delta),
+				fmt.Sprintf("%+.1f", framework.Change),
+				fmt.Sprintf("%.1f%% – %.1f%%", framework.Min, framework.Max),
+				fmt.Sprintf("%d", len(framework.Series)),
+				sparkline(framework.Series),
+			})
+		}
+		remediacao = append(remediacao, []string{
+			regiao.Region,
+			fmt.Sprintf("%d", regiao.Remediation.Remediated),
+			fmt.Sprintf("%d", regiao.Remediation.Open),
+			formatarMTTR(regiao.Remediation),
+			formatarExecucoesRemediacao(regiao.Remediation),
+		})
+	}
+	remediacao = append(remediacao, []string{
+		"Total",
+		fmt.Sprintf("%d", card.Remediation.Remediated),
+		fmt.Sprintf("%d", card.Remediation.Open),
+		formatarMTTR(card.Remediation),
+		formatarExecucoesRemediacao(card.Remediation),
+	})
+
+	for _, violacao := range card.TopViolations {
+		estado := "remediada"
+		if violacao.Open {
+			estado = "aberta"
+		}
+		violacoes = append(violacoes, []string{
+			violacao.TestID,
+			violacao.Criticality,
+			strings.Join(violacao.Requirements, ", "),
+			strings.Join(violacao.Regions, ", "),
+			fmt.Sprintf("%d/%d", violacao.Failures, violacao.Runs),
+			violacao.FirstSeen.Format("2006-01-02"),
+			violacao.LastSeen.Format("2006-01-02"),
+			estado,
+			violacao.Violation,
+		})
+	}
+	return tendencias, violacoes, remediacao
+}
+
+// Cabeçalhos das tabelas do scorecard
+var (
+	TrendHeader       = []string{"Região", "Framework", "Pontuação", "Δ Anterior", "Δ Janela", "Mín – Máx", "Execuções", "Evolução"}
+	ViolationsHeader  = []string{"Teste", "Criticidade", "Requisitos", "Regiões", "Reprovações", "Primeira", "Última", "Estado", "Violação"}
+	RemediationHeader = []string{"Região", "Remediadas", "Abertas", "MTTR", "Execuções até remediar"}
+)
+
+// WriteMarkdown escreve o scorecard em Markdown, para inclusão nos relatórios mensais de governação
+func WriteMarkdown(w io.Writer, card *Scorecard) error {
+	tendencias, violacoes, remediacao := Rows(card)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Scorecard de Compliance\n\n")
+	fmt.Fprintf(&b, "Gerado em %s, com as últimas %d execuções de cada região", card.GeneratedAt.Format("2006-01-02 15:04 MST"), card.Window)
+	if len(card.Frameworks) > 0 {
+		fmt.Fprintf(&b, " (frameworks: %s)", strings.Join(card.Frameworks, ", "))
+	}
+	b.WriteString(".\n")
+
+	secoes := []struct {
+		titulo    string
+		cabecalho []string
+		linhas    [][]string
+	}{
+		{"Tendência de compliance", TrendHeader, tendencias},
+		{"Violações recorrentes", ViolationsHeader, violacoes},
+		{"Tempo médio de remediação", RemediationHeader, remediacao},
+	}
+	for _, secao := range secoes {
+		fmt.Fprintf(&b, "\n## %s\n\n", secao.titulo)
+		if len(secao.linhas) == 0 {
+			b.WriteString("Nenhuma violação recorrente na janela.\n")
+			continue
+		}
+		escreverLinhaMarkdown(&b, secao.cabecalho)
+		separador := make([]string, len(secao.cabecalho))
+		for i := range separador {
+			separador[i] = "---"
+		}
+		escreverLinhaMarkdown(&b, separador)
+		for _, linha := range secao.linhas {
+			escreverLinhaMarkdown(&b, linha)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escreverLinhaMarkdown escreve uma linha de tabela Markdown, escapando as barras verticais das células
+func escreverLinhaMarkdown(b *strings.Builder, celulas []string) {
+	b.WriteString("|")
+	for _, celula := range celulas {
+		celula = strings.ReplaceAll(strings.ReplaceAll(celula, "|", `\|`), "\n", " ")
+		fmt.Fprintf(b, " %s |", celula)
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,206 @@
+package scorecard
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var inicioJanela = time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)
+
+// falha cria um teste reprovado associado aos frameworks indicados
+func falha(testID string, violacao string, frameworks ...string) history.Failure {
+	return history.Failure{
+		TestID:       testID,
+		Name:         "Caso " + testID,
+		Criticality:  "alta",
+		Requirements: []string{testID + "-req"},
+		Frameworks:   frameworks,
+		Violations:   []string{violacao},
+	}
+}
+
+// historicosAngola tem quatro execuções diárias de Angola, da mais recente para a mais antiga:
+// ao-aml-001 falha nas duas primeiras, ao-pd-001 na segunda e ao-aml-002 continua a falhar na última
+func historicosAngola() map[string][]history.Run {
+	pontuacoes := func(gdpr, lgpd float64) map[string]report.FrameworkScore {
+		return map[string]report.FrameworkScore{
+			"GDPR": {Name: "GDPR", TotalTests: 4, ComplianceScore: gdpr},
+			"LGPD": {Name: "LGPD", TotalTests: 2, ComplianceScore: lgpd},
+		}
+	}
+	cronologico := []history.Run{
+		{RunID: "r0", ComplianceScore: 80, FrameworkScores: pontuacoes(75, 100),
+			Failures: []history.Failure{falha("ao-aml-001", "PEP sem revisão", "GDPR")}},
+		{RunID: "r1", ComplianceScore: 85, FrameworkScores: pontuacoes(75, 50),
+			Failures: []history.Failure{falha("ao-aml-001", "limite | excedido", "GDPR"), falha("ao-pd-001", "sem consentimento", "LGPD")}},
+		{RunID: "r2", ComplianceScore: 90, FrameworkScores: pontuacoes(75, 100),
+			Failures: []history.Failure{falha("ao-aml-002", "sem alerta", "GDPR")}},
+		{RunID: "r3", ComplianceScore: 95, FrameworkScores: pontuacoes(100, 100),
+			Failures: []history.Failure{falha("ao-aml-002", "sem alerta", "GDPR")}},
+	}
+
+	historico := make([]history.Run, len(cronologico))
+	for i, run := range cronologico {
+		run.Region = "AO"
+		run.RegionName = "Angola"
+		run.ExecutedAt = inicioJanela.Add(time.Duration(i) * 24 * time.Hour)
+		historico[len(cronologico)-1-i] = run
+	}
+	return map[string][]history.Run{
+		"AO": historico,
+		"BR": {{RunID: "r9", Region: "BR", ComplianceScore: 100, ExecutedAt: inicioJanela}},
+	}
+}
+
+func TestCalculateTrends(t *testing.T) {
+	card := Calculate(historicosAngola(), nil, 4, 10)
+
+	assert.Equal(t, 4, card.Window)
+	require.Len(t, card.Regions, 2)
+	ao := card.Regions[0]
+	assert.Equal(t, "AO", ao.Region)
+	assert.Equal(t, "Angola", ao.RegionName)
+	assert.Equal(t, 4, ao.Runs)
+	assert.Equal(t, 95.0, ao.Score)
+	assert.Equal(t, 5.0, ao.Delta)
+	assert.Equal(t, 87.5, ao.Average)
+	assert.InDelta(t, 5.0, ao.Slope, 1e-9)
+	assert.Equal(t, 80.0, ao.Min)
+	assert.Equal(t, 95.0, ao.Max)
+	assert.Equal(t, 15.0, ao.Change)
+
+	// A série é cronológica, da execução mais antiga para a mais recente
+	require.Len(t, ao.Series, 4)
+	assert.Equal(t, "r0", ao.Series[0].RunID)
+	assert.Equal(t, "r3", ao.Series[3].RunID)
+
+	require.Len(t, ao.Frameworks, 2)
+	gdpr := ao.Frameworks[0]
+	assert.Equal(t, "GDPR", gdpr.ID)
+	assert.Equal(t, 25.0, gdpr.Delta)
+	assert.Equal(t, 25.0, gdpr.Change)
+	assert.Equal(t, 75.0, gdpr.Min)
+	lgpd := ao.Frameworks[1]
+	assert.Equal(t, 50.0, lgpd.Min)
+	assert.Equal(t, 0.0, lgpd.Change)
+
+	br := card.Regions[1]
+	assert.Equal(t, "BR", br.Region)
+	assert.Empty(t, br.Frameworks)
+	assert.Equal(t, Remediation{}, br.Remediation)
+}
+
+func TestCalculateRecurringViolations(t *testing.T) {
+	card := Calculate(historicosAngola(), nil, 4, 10)
+
+	// Mais reprovações primeiro; em caso de empate, as violações abertas
+	require.Len(t, card.TopViolations, 3)
+	aberta, remediada, unica := card.TopViolations[0], card.TopViolations[1], card.TopViolations[2]
+
+	assert.Equal(t, "ao-aml-002", aberta.TestID)
+	assert.True(t, aberta.Open)
+	assert.Equal(t, 2, aberta.Failures)
+	assert.Equal(t, 4, aberta.Runs)
+	assert.Equal(t, []string{"AO"}, aberta.Regions)
+	assert.Equal(t, inicioJanela.Add(48*time.Hour), aberta.FirstSeen)
+	assert.Equal(t, inicioJanela.Add(72*time.Hour), aberta.LastSeen)
+
+	assert.Equal(t, "ao-aml-001", remediada.TestID)
+	assert.False(t, remediada.Open)
+	assert.Equal(t, "PEP sem revisão", remediada.Violation, "empates entre mensagens resolvidos por ordem alfabética")
+	assert.Equal(t, []string{"ao-aml-001-req"}, remediada.Requirements)
+
+	assert.Equal(t, "ao-pd-001", unica.TestID)
+	assert.Equal(t, 1, unica.Failures)
+
+	card = Calculate(historicosAngola(), nil, 4, 2)
+	require.Len(t, card.TopViolations, 2, "--top limita a lista")
+	assert.Equal(t, "ao-aml-001", card.TopViolations[1].TestID)
+
+	card = Calculate(map[string][]history.Run{"BR": historicosAngola()["BR"]}, nil, 4, 10)
+	assert.NotNil(t, card.TopViolations, "sem violações a lista é vazia e não nula, para o JSON")
+	assert.Empty(t, card.TopViolations)
+}
+
+func TestCalculateRemediation(t *testing.T) {
+	card := Calculate(historicosAngola(), nil, 4, 10)
+
+	// ao-aml-001 é remediado após 2 execuções (48h) e ao-pd-001 após 1 (24h); ao-aml-002 continua aberto
+	esperado := Remediation{Remediated: 2, Open: 1, MeanHours: 36, MeanRuns: 1.5}
+	assert.Equal(t, esperado, card.Regions[0].Remediation)
+	assert.Equal(t, esperado, card.Remediation)
+}
+
+func TestCalculateFrameworkFilter(t *testing.T) {
+	card := Calculate(historicosAngola(), []string{" GDPR"}, 4, 10)
+
+	assert.Equal(t, []string{" GDPR"}, card.Frameworks)
+	require.Len(t, card.Regions[0].Frameworks, 1)
+	assert.Equal(t, "GDPR", card.Regions[0].Frameworks[0].ID)
+
+	// As falhas de testes que apenas cobrem outros frameworks são ignoradas
+	var ids []string
+	for _, violacao := range card.TopViolations {
+		ids = append(ids, violacao.TestID)
+	}
+	assert.Equal(t, []string{"ao-aml-002", "ao-aml-001"}, ids)
+	assert.Equal(t, Remediation{Remediated: 1, Open: 1, MeanHours: 48, MeanRuns: 2}, card.Remediation)
+}
+
+func TestSparkline(t *testing.T) {
+	serie := []Point{{Score: 0}, {Score: 50}, {Score: 80}, {Score: 100}, {Score: 120}, {Score: -5}}
+	assert.Equal(t, "▁▄▆██▁", sparkline(serie))
+	assert.Empty(t, sparkline(nil))
+}
+
+func TestFormatarMTTR(t *testing.T) {
+	tests := []struct {
+		resumo   Remediation
+		mttr     string
+		execucao string
+	}{
+		{Remediation{}, "-", "-"},
+		{Remediation{Remediated: 2, MeanHours: 36, MeanRuns: 1.5}, "1d 12h", "1.5"},
+		{Remediation{Remediated: 1, MeanHours: 1.5, MeanRuns: 1}, "1h 30m", "1.0"},
+		{Remediation{Remediated: 1, MeanHours: 0.25, MeanRuns: 1}, "15m", "1.0"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.mttr, formatarMTTR(tt.resumo))
+		assert.Equal(t, tt.execucao, formatarExecucoesRemediacao(tt.resumo))
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	card := Calculate(historicosAngola(), []string{"GDPR"}, 4, 10)
+
+	var b strings.Builder
+	require.NoError(t, WriteMarkdown(&b, card))
+	md := b.String()
+
+	assert.Contains(t, md, "# Scorecard de Compliance\n\n")
+	assert.Contains(t, md, "com as últimas 4 execuções de cada região (frameworks: GDPR).\n")
+	assert.Contains(t, md, "| Região | Framework | Pontuação | Δ Anterior | Δ Janela | Mín – Máx | Execuções | Evolução |\n| --- |")
+	assert.Contains(t, md, "| AO | Global | 95.0% | +5.0 | +15.0 | 80.0% – 95.0% | 4 | ▆▆▇▇ |\n")
+	assert.Contains(t, md, "| AO | GDPR | 100.0% | +25.0 | +25.0 | 75.0% – 100.0% | 4 | ▆▆▆█ |\n")
+	assert.Contains(t, md, "| ao-aml-002 | alta | ao-aml-002-req | AO | 2/4 | 2026-04-03 | 2026-04-04 | aberta | sem alerta |\n")
+	assert.Contains(t, md, "| Total | 1 | 1 | 2d 0h | 2.0 |\n")
+
+	// As barras verticais das células são escapadas
+	card = Calculate(historicosAngola(), nil, 4, 10)
+	card.TopViolations[1].Violation = "limite | excedido"
+	b.Reset()
+	require.NoError(t, WriteMarkdown(&b, card))
+	assert.Contains(t, b.String(), "| limite \\| excedido |\n")
+
+	// Sem violações a secção indica que a janela não tem reprovações
+	card = Calculate(map[string][]history.Run{"BR": historicosAngola()["BR"]}, nil, 4, 10)
+	b.Reset()
+	require.NoError(t, WriteMarkdown(&b, card))
+	assert.Contains(t, b.String(), "## Violações recorrentes\n\nNenhuma violação recorrente na janela.\n")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/selection"
+)
+
+// seletorTestes aplica a seleção de --tags, --exclude-file e --shard aos casos de teste das matrizes
+type seletorTestes struct {
+	*selection.Selector
+}
+
+// novoSeletorTestes cria o seletor a partir da configuração da CLI
+func novoSeletorTestes(config Config) (*seletorTestes, error) {
+	seletor, err := selection.New(config.Tags, config.ExcludeFiles, config.Shard)
+	if err != nil {
+		return nil, err
+	}
+	return &seletorTestes{Selector: seletor}, nil
+}
+
+// selecionar filtra os casos de teste e retorna-os ordenados por ID, para uma seleção determinística
+func (s *seletorTestes) selecionar(testCases []TestCase, reqToFramework, reqToCriticality map[string]string) []TestCase {
+	var selecionados []TestCase
+	for _, testCase := range testCases {
+		alvo := &selection.Target{
+			ID:           testCase.ID,
+			Category:     testCase.Category,
+			Tags:         testCase.Tags,
+			Criticality:  criticidadeCasoTeste(testCase, reqToCriticality),
+			Frameworks:   frameworksCasoTeste(testCase, reqToFramework),
+			Requirements: testCase.RequirementIDs,
+		}
+		if s.Selects(alvo) {
+			selecionados = append(selecionados, testCase)
+		}
+	}
+
+	sort.SliceStable(selecionados, func(i, j int) bool { return selecionados[i].ID < selecionados[j].ID })
+	return selecionados
+}
+
+// listarCasosSelecionados escreve os casos de teste selecionados de uma região, um por linha
+func listarCasosSelecionados(w io.Writer, region string, testCases []TestCase) {
+	for _, testCase := range testCases {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", region, testCase.ID, testCase.PolicyPath)
+	}
+}
+
+// criticidadeCasoTeste retorna a criticidade mais alta entre os requisitos do caso de teste
+func criticidadeCasoTeste(testCase TestCase, reqToCriticality map[string]string) string {
+	resultado := ""
+	for _, reqID := range testCase.RequirementIDs {
+		if criticality, ok := reqToCriticality[reqID]; ok {
+			if resultado == "" || criticality == "alta" ||
+				(criticality == "média" && resultado == "baixa") {
+				resultado = criticality
+			}
+		}
+	}
+	return resultado
+}
+
+// frameworksCasoTeste retorna os frameworks dos requisitos do caso de teste
+func frameworksCasoTeste(testCase TestCase, reqToFramework map[string]string) []string {
+	var frameworks []string
+	for _, reqID := range testCase.RequirementIDs {
+		if frameworkID, ok := reqToFramework[reqID]; ok && !contains(frameworks, frameworkID) {
+			frameworks = append(frameworks, frameworkID)
+		}
+	}
+	return frameworks
+}
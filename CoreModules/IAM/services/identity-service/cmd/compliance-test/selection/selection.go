@@ -0,0 +1,360 @@
+// Package selection seleciona os casos de teste de compliance com expressões booleanas de tags,
+// arquivos de exclusão e partição em shards
+package selection
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Atributos dos casos de teste que podem ser usados como prefixo numa expressão de tags
+// (ex: criticality:alta, framework:BNA, requirement:ao-aml-*, category:aml_kyc, id:ao-pd-*)
+const (
+	atributoCriticidade = "criticality"
+	atributoFramework   = "framework"
+	atributoRequisito   = "requirement"
+	atributoCategoria   = "category"
+	atributoID          = "id"
+)
+
+// Sinónimos em inglês dos níveis de criticidade das matrizes de conformidade
+var sinonimosCriticidade = map[string]string{
+	"high":   "alta",
+	"medium": "média",
+	"media":  "média",
+	"low":    "baixa",
+}
+
+// Target reúne os atributos de um caso de teste avaliados pela expressão de tags
+type Target struct {
+	ID           string
+	Category     string
+	Tags         []string
+	Criticality  string
+	Frameworks   []string
+	Requirements []string
+}
+
+// Expression é um nó da árvore de uma expressão booleana de tags
+type Expression interface {
+	Matches(alvo *Target) bool
+	String() string
+}
+
+type exprNao struct{ operando Expression }
+type exprE struct{ esquerda, direita Expression }
+type exprOu struct{ esquerda, direita Expression }
+type exprTag struct{ valor string }
+
+func (e exprNao) Matches(alvo *Target) bool { return !e.operando.Matches(alvo) }
+func (e exprE) Matches(alvo *Target) bool {
+	return e.esquerda.Matches(alvo) && e.direita.Matches(alvo)
+}
+func (e exprOu) Matches(alvo *Target) bool {
+	return e.esquerda.Matches(alvo) || e.direita.Matches(alvo)
+}
+
+func (e exprNao) String() string { return "!" + e.operando.String() }
+func (e exprE) String() string   { return "(" + e.esquerda.String() + " && " + e.direita.String() + ")" }
+func (e exprOu) String() string  { return "(" + e.esquerda.String() + " || " + e.direita.String() + ")" }
+func (e exprTag) String() string { return e.valor }
+
+// Matches verifica a tag literal ou, com prefixo de atributo, o atributo do caso de teste
+func (e exprTag) Matches(alvo *Target) bool {
+	if algumaIgual(alvo.Tags, e.valor) {
+		return true
+	}
+
+	atributo, valor, ok := strings.Cut(e.valor, ":")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(atributo) {
+	case atributoCriticidade:
+		return strings.EqualFold(NormalizeCriticality(valor), NormalizeCriticality(alvo.Criticality))
+	case atributoFramework:
+		return algumaIgual(alvo.Frameworks, valor)
+	case atributoRequisito:
+		return correspondeAlgum(alvo.Requirements, valor)
+	case atributoCategoria:
+		return strings.EqualFold(alvo.Category, valor)
+	case atributoID:
+		return correspondeAlgum([]string{alvo.ID}, valor)
+	}
+	return false
+}
+
+// algumaIgual verifica se algum valor é igual ao indicado, sem distinguir maiúsculas
+func algumaIgual(valores []string, valor string) bool {
+	for _, v := range valores {
+		if strings.EqualFold(v, valor) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeCriticality converte os sinónimos em inglês para os níveis usados nas matrizes
+func NormalizeCriticality(valor string) string {
+	valor = strings.ToLower(strings.TrimSpace(valor))
+	if normalizado, ok := sinonimosCriticidade[valor]; ok {
+		return normalizado
+	}
+	return valor
+}
+
+// correspondeAlgum verifica se algum valor corresponde ao padrão glob, sem distinguir maiúsculas
+func correspondeAlgum(valores []string, padrao string) bool {
+	padrao = strings.ToLower(padrao)
+	for _, v := range valores {
+		if ok, _ := path.Match(padrao, strings.ToLower(v)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parserTags analisa expressões de tags com a precedência ! > && > ||
+// Vírgulas equivalem a ||, mantendo a compatibilidade com a lista simples de --tags
+type parserTags struct {
+	tokens []string
+	pos    int
+}
+
+// ParseExpression converte o texto de --tags numa expressão; texto vazio retorna nil e seleciona todos os testes
+func ParseExpression(texto string) (Expression, error) {
+	tokens, err := tokenizarTags(texto)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &parserTags{tokens: tokens}
+	expr, err := p.ou()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("expressão de tags inválida: token inesperado %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenizarTags separa operadores, parênteses e tags
+func tokenizarTags(texto string) ([]string, error) {
+	var tokens []string
+	runes := []rune(texto)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '!':
+			tokens = append(tokens, string(r))
+			i++
+		case r == ',':
+			tokens = append(tokens, "||")
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("expressão de tags inválida: use %c%c na posição %d", r, r, i+1)
+			}
+			tokens = append(tokens, string([]rune{r, r}))
+			i += 2
+		default:
+			inicio := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()!,&|", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[inicio:i]))
+		}
+	}
+	return tokens, nil
+}
+
+func (p *parserTags) proximo() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parserTags) ou() (Expression, error) {
+	esquerda, err := p.e()
+	if err != nil {
+		return nil, err
+	}
+	for p.proximo() == "||" {
+		p.pos++
+		direita, err := p.e()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = exprOu{esquerda, direita}
+	}
+	return esquerda, nil
+}
+
+func (p *parserTags) e() (Expression, error) {
+	esquerda, err := p.unario()
+	if err != nil {
+		return nil, err
+	}
+	for p.proximo() == "&&" {
+		p.pos++
+		direita, err := p.unario()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = exprE{esquerda, direita}
+	}
+	return esquerda, nil
+}
+
+func (p *parserTags) unario() (Expression, error) {
+	token := p.proximo()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("expressão de tags inválida: fim inesperado")
+	case "!":
+		p.pos++
+		operando, err := p.unario()
+		if err != nil {
+			return nil, err
+		}
+		return exprNao{operando}, nil
+	case "(":
+		p.pos++
+		expr, err := p.ou()
+		if err != nil {
+			return nil, err
+		}
+		if p.proximo() != ")" {
+			return nil, fmt.Errorf("expressão de tags inválida: parêntese não fechado")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("expressão de tags inválida: token inesperado %q", token)
+	}
+	p.pos++
+	return exprTag{token}, nil
+}
+
+// Selector aplica a expressão de tags, as exclusões e a partição em shards aos casos de teste
+type Selector struct {
+	expressao   Expression
+	exclusoes   []string // IDs ou padrões glob de casos de teste excluídos
+	shardIndice int      // Shard desta execução, a partir de 1
+	shardTotal  int
+}
+
+// New cria o seletor a partir de --tags, --exclude-file e --shard
+func New(tags string, excludeFiles []string, shard string) (*Selector, error) {
+	expressao, err := ParseExpression(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	seletor := &Selector{expressao: expressao, shardIndice: 1, shardTotal: 1}
+	for _, arquivo := range excludeFiles {
+		exclusoes, err := LoadExclusions(arquivo)
+		if err != nil {
+			return nil, err
+		}
+		seletor.exclusoes = append(seletor.exclusoes, exclusoes...)
+	}
+
+	if shard != "" {
+		seletor.shardIndice, seletor.shardTotal, err = ParseShard(shard)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return seletor, nil
+}
+
+// Expression retorna a expressão de tags do seletor, nil sem --tags
+func (s *Selector) Expression() Expression {
+	return s.expressao
+}
+
+// Selects indica se o caso de teste não está excluído, satisfaz a expressão de tags e pertence ao shard
+func (s *Selector) Selects(alvo *Target) bool {
+	if s.Excluded(alvo.ID) {
+		return false
+	}
+	if s.expressao != nil && !s.expressao.Matches(alvo) {
+		return false
+	}
+	return s.InShard(alvo.ID)
+}
+
+// LoadExclusions lê um arquivo de exclusões: um ID ou padrão glob por linha, # inicia comentário
+func LoadExclusions(arquivo string) ([]string, error) {
+	f, err := os.Open(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo de exclusões %s: %w", arquivo, err)
+	}
+	defer f.Close()
+
+	var exclusoes []string
+	scanner := bufio.NewScanner(f)
+	linha := 0
+	for scanner.Scan() {
+		linha++
+		entrada, _, _ := strings.Cut(scanner.Text(), "#")
+		entrada = strings.TrimSpace(entrada)
+		if entrada == "" {
+			continue
+		}
+		if _, err := path.Match(entrada, ""); err != nil {
+			return nil, fmt.Errorf("padrão inválido em %s:%d: %q", arquivo, linha, entrada)
+		}
+		exclusoes = append(exclusoes, entrada)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de exclusões %s: %w", arquivo, err)
+	}
+	return exclusoes, nil
+}
+
+// ParseShard interpreta --shard no formato K/N, com 1 <= K <= N
+func ParseShard(texto string) (int, int, error) {
+	k, n, ok := strings.Cut(texto, "/")
+	indice, errK := strconv.Atoi(strings.TrimSpace(k))
+	total, errN := strconv.Atoi(strings.TrimSpace(n))
+	if !ok || errK != nil || errN != nil || total < 1 || indice < 1 || indice > total {
+		return 0, 0, fmt.Errorf("shard inválido %q: use K/N com 1 <= K <= N", texto)
+	}
+	return indice, total, nil
+}
+
+// Excluded verifica se o caso de teste consta dos arquivos de exclusão
+func (s *Selector) Excluded(id string) bool {
+	for _, padrao := range s.exclusoes {
+		if correspondeAlgum([]string{id}, padrao) {
+			return true
+		}
+	}
+	return false
+}
+
+// InShard atribui cada caso de teste a um shard pelo hash do ID, estável entre execuções
+// e independente dos restantes testes da suíte
+func (s *Selector) InShard(id string) bool {
+	if s.shardTotal <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32()%uint32(s.shardTotal)) == s.shardIndice-1
+}
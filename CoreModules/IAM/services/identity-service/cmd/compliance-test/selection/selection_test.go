@@ -0,0 +1,269 @@
+package selection
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// alvoAML é um caso de teste de AML angolano com requisitos de criticidade alta
+func alvoAML() *Target {
+	return &Target{
+		ID:           "ao-aml-001",
+		Category:     "aml_kyc",
+		Tags:         []string{"AML", "kyc", "angola"},
+		Criticality:  "alta",
+		Frameworks:   []string{"BNA", "FATF"},
+		Requirements: []string{"ao-aml-req-01", "ao-aml-req-02"},
+	}
+}
+
+func TestParseExpressionPrecedence(t *testing.T) {
+	tests := []struct {
+		nome     string
+		texto    string
+		esperado string
+	}{
+		{"tag simples", "aml", "aml"},
+		{"e tem precedência sobre ou", "a || b && c", "(a || (b && c))"},
+		{"e tem precedência sobre ou à esquerda", "a && b || c", "((a && b) || c)"},
+		{"negação tem precedência sobre e", "!a && b", "(!a && b)"},
+		{"parênteses alteram a precedência", "(a || b) && c", "((a || b) && c)"},
+		{"negação de grupo", "!(a || b)", "!(a || b)"},
+		{"dupla negação", "!!a", "!!a"},
+		{"associatividade à esquerda", "a || b || c", "((a || b) || c)"},
+		{"vírgula equivale a ou", "a, b && c", "(a || (b && c))"},
+		{"espaços opcionais", "a&&!b", "(a && !b)"},
+		{"tags com atributo", "criticality:alta && framework:BNA", "(criticality:alta && framework:BNA)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			expr, err := ParseExpression(tt.texto)
+			require.NoError(t, err)
+			require.NotNil(t, expr)
+			assert.Equal(t, tt.esperado, expr.String())
+		})
+	}
+}
+
+func TestParseExpressionEmpty(t *testing.T) {
+	for _, texto := range []string{"", "   "} {
+		expr, err := ParseExpression(texto)
+		require.NoError(t, err)
+		assert.Nil(t, expr, "texto vazio seleciona todos os testes")
+	}
+}
+
+func TestParseExpressionMalformed(t *testing.T) {
+	tests := []struct {
+		nome  string
+		texto string
+		erro  string
+	}{
+		{"e simples", "a & b", "use && na posição 3"},
+		{"ou simples", "a | b", "use || na posição 3"},
+		{"e no fim do texto", "a &", "use && na posição 3"},
+		{"operador sem operando à direita", "a &&", "fim inesperado"},
+		{"operador sem operando à esquerda", "&& a", `token inesperado "&&"`},
+		{"ou duplicado", "a || || b", `token inesperado "||"`},
+		{"negação sem operando", "!", "fim inesperado"},
+		{"parêntese não fechado", "(a || b", "parêntese não fechado"},
+		{"parêntese a mais", "a)", `token inesperado ")"`},
+		{"parênteses vazios", "()", `token inesperado ")"`},
+		{"tags sem operador", "a b", `token inesperado "b"`},
+		{"vírgula no fim", "a,", "fim inesperado"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			expr, err := ParseExpression(tt.texto)
+			require.Error(t, err)
+			assert.Nil(t, expr)
+			assert.Contains(t, err.Error(), "expressão de tags inválida")
+			assert.Contains(t, err.Error(), tt.erro)
+		})
+	}
+}
+
+func TestExpressionMatches(t *testing.T) {
+	tests := []struct {
+		nome     string
+		texto    string
+		esperado bool
+	}{
+		{"tag presente", "aml", true},
+		{"tag sem distinguir maiúsculas", "KYC", true},
+		{"tag ausente", "gdpr", false},
+		{"negação de tag presente", "!aml", false},
+		{"negação de tag ausente", "!gdpr", true},
+		{"dupla negação", "!!aml", true},
+		{"e com um termo falso", "aml && gdpr", false},
+		{"ou com um termo verdadeiro", "gdpr || aml", true},
+		{"precedência sem parênteses", "gdpr && aml || kyc", true},
+		{"precedência com parênteses", "gdpr && (aml || kyc)", false},
+		{"negação de grupo", "!(gdpr || lgpd)", true},
+		{"lista com vírgulas", "gdpr, angola", true},
+		{"criticidade", "criticality:alta", true},
+		{"criticidade com sinónimo em inglês", "criticality:HIGH", true},
+		{"outra criticidade", "criticality:low", false},
+		{"framework", "framework:bna", true},
+		{"framework ausente", "framework:GDPR", false},
+		{"requisito com padrão glob", "requirement:ao-aml-*", true},
+		{"requisito sem correspondência", "requirement:br-*", false},
+		{"categoria", "category:AML_KYC", true},
+		{"ID com padrão glob", "id:ao-aml-*", true},
+		{"ID exato de outro caso", "id:ao-pd-001", false},
+		{"atributo desconhecido", "owner:compliance", false},
+		{"combinação de atributos", "framework:BNA && !criticality:baixa && id:ao-*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			expr, err := ParseExpression(tt.texto)
+			require.NoError(t, err)
+			assert.Equal(t, tt.esperado, expr.Matches(alvoAML()))
+		})
+	}
+}
+
+func TestNormalizeCriticality(t *testing.T) {
+	tests := []struct {
+		valor    string
+		esperado string
+	}{
+		{"high", "alta"},
+		{" Medium ", "média"},
+		{"media", "média"},
+		{"LOW", "baixa"},
+		{"Alta", "alta"},
+		{"crítica", "crítica"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.esperado, NormalizeCriticality(tt.valor), tt.valor)
+	}
+}
+
+// escreverExclusoes grava um arquivo de exclusões temporário
+func escreverExclusoes(t *testing.T, conteudo string) string {
+	t.Helper()
+	arquivo := filepath.Join(t.TempDir(), "exclusoes.txt")
+	require.NoError(t, os.WriteFile(arquivo, []byte(conteudo), 0600))
+	return arquivo
+}
+
+func TestLoadExclusions(t *testing.T) {
+	arquivo := escreverExclusoes(t, `# casos instáveis
+ao-aml-001
+  br-lgpd-*   # em revisão
+
+mz-*-003
+`)
+	exclusoes, err := LoadExclusions(arquivo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ao-aml-001", "br-lgpd-*", "mz-*-003"}, exclusoes)
+
+	_, err = LoadExclusions(escreverExclusoes(t, "ao-aml-001\nao-[pd\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `:2: "ao-[pd"`)
+
+	_, err = LoadExclusions(filepath.Join(t.TempDir(), "inexistente.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseShard(t *testing.T) {
+	validos := []struct {
+		texto  string
+		indice int
+		total  int
+	}{
+		{"1/1", 1, 1},
+		{"2/4", 2, 4},
+		{" 3 / 3 ", 3, 3},
+	}
+	for _, tt := range validos {
+		indice, total, err := ParseShard(tt.texto)
+		require.NoError(t, err, tt.texto)
+		assert.Equal(t, tt.indice, indice)
+		assert.Equal(t, tt.total, total)
+	}
+
+	for _, texto := range []string{"", "2", "0/3", "4/3", "1/0", "-1/2", "a/b", "1/2/3"} {
+		_, _, err := ParseShard(texto)
+		assert.Error(t, err, "shard %q deve ser rejeitado", texto)
+	}
+}
+
+func TestSelectorShards(t *testing.T) {
+	ids := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		ids = append(ids, fmt.Sprintf("ao-aml-%03d", i))
+	}
+
+	// Cada caso de teste pertence a exatamente um shard e a partição cobre toda a suíte
+	const total = 4
+	contagem := make(map[string]int)
+	for indice := 1; indice <= total; indice++ {
+		seletor, err := New("", nil, fmt.Sprintf("%d/%d", indice, total))
+		require.NoError(t, err)
+
+		noShard := 0
+		for _, id := range ids {
+			if seletor.InShard(id) {
+				contagem[id]++
+				noShard++
+			}
+		}
+		assert.Greater(t, noShard, 0, "shard %d vazio", indice)
+	}
+	for _, id := range ids {
+		assert.Equal(t, 1, contagem[id], id)
+	}
+
+	// Sem --shard todos os casos pertencem à execução
+	seletor, err := New("", nil, "")
+	require.NoError(t, err)
+	assert.True(t, seletor.InShard("ao-aml-001"))
+}
+
+func TestSelectorSelects(t *testing.T) {
+	exclusoes := escreverExclusoes(t, "ao-aml-00*\n")
+
+	tests := []struct {
+		nome         string
+		tags         string
+		excludeFiles []string
+		id           string
+		esperado     bool
+	}{
+		{"sem filtros seleciona tudo", "", nil, "ao-aml-001", true},
+		{"expressão satisfeita", "aml && !gdpr", nil, "ao-aml-001", true},
+		{"expressão não satisfeita", "gdpr", nil, "ao-aml-001", false},
+		{"exclusão prevalece sobre a expressão", "aml", []string{exclusoes}, "ao-aml-001", false},
+		{"fora do padrão de exclusão", "aml", []string{exclusoes}, "ao-aml-010", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			seletor, err := New(tt.tags, tt.excludeFiles, "")
+			require.NoError(t, err)
+
+			alvo := alvoAML()
+			alvo.ID = tt.id
+			assert.Equal(t, tt.esperado, seletor.Selects(alvo))
+		})
+	}
+
+	// Erros da expressão, das exclusões e do shard impedem a criação do seletor
+	_, err := New("aml &&", nil, "")
+	assert.Error(t, err)
+	_, err = New("", []string{filepath.Join(t.TempDir(), "inexistente.txt")}, "")
+	assert.Error(t, err)
+	_, err = New("", nil, "5/4")
+	assert.Error(t, err)
+}
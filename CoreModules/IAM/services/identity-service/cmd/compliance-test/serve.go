@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/alerts"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/pdp"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/schedule"
+	"go.uber.org/zap"
+
+	"innovabiz/iam/identity-service/internal/infrastructure/notification"
+)
+
+// Valores padrão do modo serve
+const (
+	agendamentoPadrao        = "0 */6 * * *"
+	limitePontuacaoPadrao    = 90.0
+	execucoesTendencia       = 10
+	tempoLimiteAlertas       = 30 * time.Second
+	tempoLimiteRegistoPadrao = 10 * time.Second
+)
+
+// servico executa periodicamente as suítes regionais, grava os sumários e envia os alertas de desvio
+type servico struct {
+	logger    *zap.Logger
+	config    Config
+	seletor   *seletorTestes
+	registo   *registoExecucoes
+	limites   alerts.Thresholds
+	canais    []alerts.Channel
+	runsTrend int
+}
+
+// runServe inicia o modo serve: executa as suítes regionais segundo o agendamento até receber
+// SIGINT ou SIGTERM, e retorna o código de saída do processo
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	agendamentoStr := fs.String("schedule", agendamentoPadrao, "Agendamento cron de cinco campos (minuto hora dia mês dia-da-semana), @daily, @hourly ou @every <duração>")
+	runOnStart := fs.Bool("run-on-start", false, "Executar as suítes imediatamente ao iniciar, além do agendamento")
+	databaseURL := fs.String("database-url", os.Getenv("COMPLIANCE_DATABASE_URL"), "URL do Postgres onde gravar os sumários (padrão: variável COMPLIANCE_DATABASE_URL)")
+	threshold := fs.Float64("score-threshold", limitePontuacaoPadrao, "Pontuação mínima (0-100) de cada framework antes de alertar")
+	frameworkThresholds := fs.String("framework-thresholds", "", "Pontuação mínima por framework, separada por vírgula (ex: LGPD=95,GDPR=90)")
+	trendRuns := fs.Int("trend-runs", execucoesTendencia, "Número de execuções usadas no cálculo da tendência")
+	webhookURL := fs.String("webhook-url", "", "URL que recebe os alertas em JSON")
+	webhookSecret := fs.String("webhook-secret", os.Getenv("COMPLIANCE_WEBHOOK_SECRET"), "Segredo HMAC para assinar os alertas do webhook (padrão: variável COMPLIANCE_WEBHOOK_SECRET)")
+	smtpHost := fs.String("smtp-host", "", "Servidor SMTP para os alertas por email")
+	smtpPort := fs.Int("smtp-port", 587, "Porta do servidor SMTP")
+	smtpUser := fs.String("smtp-user", "", "Usuário SMTP (vazio para relé sem autenticação)")
+	smtpPassword := fs.String("smtp-password", os.Getenv("SMTP_PASSWORD"), "Senha SMTP (padrão: variável SMTP_PASSWORD)")
+	emailFrom := fs.String("email-from", "", "Remetente dos alertas por email")
+	emailTo := fs.String("email-to", "", "Destinatários dos alertas por email (separados por vírgula)")
+
+	// Seleção e alvo das suítes, com o mesmo significado das opções da execução única
+	opaPath := fs.String("opa", "./policies", "Caminho raiz das políticas OPA")
+	testsDir := fs.String("tests", "./tests/opa-compliance", "Diretório dos testes de compliance")
+	outputDir := fs.String("output", "./reports", "Diretório para salvar relatórios")
+	regionStr := fs.String("regions", "AO", "Regiões a testar (separadas por vírgula)")
+	frameworkStr := fs.String("frameworks", "", "Frameworks a testar (separados por vírgula)")
+	tagStr := fs.String("tags", "", "Expressão de tags a selecionar")
+	excludeStr := fs.String("exclude-file", "", "Arquivos com IDs ou padrões de casos de teste a excluir (separados por vírgula)")
+	jsonReport := fs.Bool("json", false, "Gerar relatório JSON em cada execução")
+	htmlReport := fs.Bool("html", false, "Gerar relatório HTML em cada execução")
+	bundlePath := fs.String("bundle", "", "Bundle OPA (diretório ou .tar.gz) a avaliar em vez de --opa, relido em cada execução")
+	bundleVerifyKey := fs.String("bundle-verify-key", "", "Arquivo com a chave pública ou segredo para verificar a assinatura do bundle")
+	bundleVerifyKeyID := fs.String("bundle-verify-key-id", "", "ID da chave de verificação (padrão: default)")
+	bundleVerifyAlg := fs.String("bundle-verify-alg", "", "Algoritmo de assinatura do bundle (padrão: RS256 para PEM, HS256 para segredos)")
+	bundleVerifyScope := fs.String("bundle-verify-scope", "", "Escopo esperado na assinatura do bundle")
+	bundleSkipVerify := fs.Bool("bundle-skip-verify", false, "Não verificar a assinatura do bundle")
+	dataStr := fs.String("data", "", "Documentos de dados e políticas auxiliares adicionais (separados por vírgula)")
+	target := fs.String("target", alvoLocal, "Alvo de execução: local ou pdp")
+	pdpURL := fs.String("pdp-url", "", "URL base do PDP, usada com --target pdp")
+	pdpToken := fs.String("pdp-token", os.Getenv("PDP_TOKEN"), "Token bearer para a API do PDP (padrão: variável PDP_TOKEN)")
+	pdpTimeout := fs.Duration("pdp-timeout", pdp.DefaultTimeout, "Tempo máximo de espera por cada decisão do PDP")
+	verbose := fs.Bool("verbose", false, "Modo verboso")
+	fs.Parse(args)
+
+	if *databaseURL == "" {
+		fmt.Fprintln(os.Stderr, "uso: compliance-test serve --database-url <postgres://...> [--schedule \"0 */6 * * *\"] [--webhook-url <url>] [--smtp-host <host> --email-from <remetente> --email-to <destinatários>]")
+		return 2
+	}
+
+	logger, err := setupLogger(*verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erro ao configurar logger: %v\n", err)
+		return 1
+	}
+
+	ag, err := schedule.Parse(*agendamentoStr)
+	if err != nil {
+		logger.Error("Agendamento inválido", zap.Error(err))
+		return 2
+	}
+	limites, err := alerts.ParseThresholds(*threshold, *frameworkThresholds)
+	if err != nil {
+		logger.Error("Limites de pontuação inválidos", zap.Error(err))
+		return 2
+	}
+
+	// A remediação altera as políticas e exige aprovação, pelo que não é executada no modo serve
+	config := Config{
+		OPAPath:           *opaPath,
+		TestsDir:          *testsDir,
+		OutputDir:         *outputDir,
+		Regions:           strings.Split(*regionStr, ","),
+		Tags:              *tagStr,
+		Json:              *jsonReport,
+		HTML:              *htmlReport,
+		HistoryRuns:       defaultHistoryRuns,
+		BundlePath:        *bundlePath,
+		BundleVerifyKey:   *bundleVerifyKey,
+		BundleVerifyKeyID: *bundleVerifyKeyID,
+		BundleVerifyAlg:   *bundleVerifyAlg,
+		BundleVerifyScope: *bundleVerifyScope,
+		BundleSkipVerify:  *bundleSkipVerify,
+		Target:            *target,
+		PDPURL:            *pdpURL,
+		PDPToken:          *pdpToken,
+		PDPTimeout:        *pdpTimeout,
+		Verbose:           *verbose,
+	}
+	if *frameworkStr != "" {
+		config.Frameworks = strings.Split(*frameworkStr, ",")
+	}
+	if *excludeStr != "" {
+		config.ExcludeFiles = strings.Split(*excludeStr, ",")
+	}
+	if *dataStr != "" {
+		config.DataPaths = strings.Split(*dataStr, ",")
+	}
+	if err := validarAlvo(config); err != nil {
+		logger.Error("Opções de execução inválidas", zap.Error(err))
+		return 2
+	}
+	seletor, err := novoSeletorTestes(config)
+	if err != nil {
+		logger.Error("Erro na seleção de casos de teste", zap.Error(err))
+		return 2
+	}
+
+	var canais []alerts.Channel
+	if *webhookURL != "" {
+		canais = append(canais, alerts.NewWebhook(*webhookURL, *webhookSecret, &http.Client{Timeout: tempoLimiteAlertas}))
+	}
+	if *smtpHost != "" {
+		sender, err := notification.NewSMTPSender(notification.SMTPConfig{
+			Host:     *smtpHost,
+			Port:     *smtpPort,
+			Username: *smtpUser,
+			Password: *smtpPassword,
+			From:     *emailFrom,
+		})
+		if err != nil {
+			logger.Error("Configuração de email inválida", zap.Error(err))
+			return 2
+		}
+		var destinatarios []string
+		for _, d := range strings.Split(*emailTo, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				destinatarios = append(destinatarios, d)
+			}
+		}
+		if len(destinatarios) == 0 {
+			logger.Error("Configuração de email inválida", zap.Error(fmt.Errorf("--smtp-host requer --email-to")))
+			return 2
+		}
+		canais = append(canais, &canalEmail{sender: sender, destinatarios: destinatarios})
+	}
+	if len(canais) == 0 {
+		logger.Warn("Nenhum canal de alerta configurado; os desvios serão apenas registados no log e no Postgres")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	registo, err := abrirRegistoExecucoes(ctx, *databaseURL)
+	if err != nil {
+		logger.Error("Erro ao abrir o registo de execuções", zap.Error(err))
+		return 1
+	}
+	defer registo.Close()
+
+	s := &servico{
+		logger:    logger,
+		config:    config,
+		seletor:   seletor,
+		registo:   registo,
+		limites:   limites,
+		canais:    canais,
+		runsTrend: *trendRuns,
+	}
+	if s.runsTrend < 2 {
+		s.runsTrend = 2
+	}
+
+	logger.Info("Modo serve iniciado",
+		zap.Stringer("schedule", ag),
+		zap.Strings("regions", config.Regions),
+		zap.Float64("score_threshold", limites.Default()),
+		zap.Int("alert_channels", len(canais)))
+
+	if *runOnStart {
+		s.executar(ctx)
+	}
+
+	for {
+		proxima := ag.Next(time.Now())
+		if proxima.IsZero() {
+			logger.Error("O agendamento não tem próximas execuções", zap.Stringer("schedule", ag))
+			return 1
+		}
+		logger.Info("Próxima execução agendada", zap.Time("at", proxima))
+
+		timer := time.NewTimer(time.Until(proxima))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info("Modo serve terminado")
+			return 0
+		case <-timer.C:
+			s.executar(ctx)
+		}
+	}
+}
+
+// executar corre as suítes de todas as regiões, grava os sumários, calcula as tendências e envia os alertas
+// As políticas e o bundle são recarregados em cada execução, para avaliar a versão publicada no momento
+func (s *servico) executar(ctx context.Context) {
+	runID, err := novoIDExecucao()
+	if err != nil {
+		s.logger.Error("Erro ao iniciar execução agendada", zap.Error(err))
+		return
+	}
+	logger := s.logger.With(zap.String("run_id", runID))
+	logger.Info("Execução agendada iniciada", zap.Strings("regions", s.config.Regions))
+
+	env, err := carregarAmbientePoliticas(logger, s.config)
+	if err != nil {
+		logger.Error("Erro ao preparar políticas OPA", zap.Error(err))
+		return
+	}
+	revisao := ""
+	if env.bundle != nil {
+		revisao = env.bundle.Manifest.Revision
+	}
+
+	for _, region := range s.config.Regions {
+		if ctx.Err() != nil {
+			return
+		}
+
+		summary := executarTestesRegionais(logger, s.config, env, s.seletor, region)
+		if summary == nil {
+			continue
+		}
+		execucao := history.NewRun(runID, revisao, summary)
+		s.registar(ctx, logger, execucao)
+	}
+
+	if !verificarRevisaoPDP(logger, env) {
+		logger.Warn("O PDP avalia uma revisão diferente do bundle de origem")
+	}
+}
+
+// registar grava o sumário da região e avalia os desvios face à execução anterior
+func (s *servico) registar(ctx context.Context, logger *zap.Logger, execucao history.Run) {
+	ctxRegisto, cancel := context.WithTimeout(ctx, tempoLimiteRegistoPadrao)
+	defer cancel()
+
+	if err := s.registo.Save(ctxRegisto, execucao); err != nil {
+		logger.Error("Erro ao gravar sumário de compliance", zap.String("region", execucao.Region), zap.Error(err))
+		return
+	}
+
+	historico, err := s.registo.History(ctxRegisto, execucao.Region, s.runsTrend)
+	if err != nil {
+		logger.Error("Erro ao consultar histórico de compliance", zap.String("region", execucao.Region), zap.Error(err))
+		return
+	}
+	tendencia := history.CalculateTrend(historico)
+	if tendencia != nil {
+		logger.Info("Tendência de compliance",
+			zap.String("region", execucao.Region),
+			zap.Float64("score", tendencia.Score),
+			zap.Float64("delta", tendencia.Delta),
+			zap.Float64("average", tendencia.Average),
+			zap.Float64("slope_per_run", tendencia.Slope),
+			zap.Int("runs", tendencia.Runs))
+	}
+
+	var anterior *history.Run
+	if len(historico) > 1 {
+		anterior = &historico[1]
+	}
+	alertas := alerts.Evaluate(execucao, anterior, s.limites)
+	if len(alertas) == 0 {
+		return
+	}
+	for _, alerta := range alertas {
+		logger.Warn("Desvio de compliance",
+			zap.String("region", alerta.Region),
+			zap.String("kind", alerta.Kind),
+			zap.String("framework", alerta.FrameworkID),
+			zap.String("requirement", alerta.RequirementID),
+			zap.String("message", alerta.Message))
+	}
+
+	ctxAlertas, cancelAlertas := context.WithTimeout(ctx, tempoLimiteAlertas)
+	defer cancelAlertas()
+
+	canais, errEntrega := alerts.Deliver(ctxAlertas, s.canais, alerts.Notification{
+		Region: execucao.Region,
+		RunID:  execucao.RunID,
+		Alerts: alertas,
+		Trend:  tendencia,
+		SentAt: time.Now().UTC(),
+	})
+	if errEntrega != nil {
+		logger.Error("Erro ao enviar alertas de compliance", zap.String("region", execucao.Region), zap.Error(errEntrega))
+	}
+
+	ctxGravacao, cancelGravacao := context.WithTimeout(ctx, tempoLimiteRegistoPadrao)
+	defer cancelGravacao()
+	for _, alerta := range alertas {
+		if err := s.registo.gravarAlerta(ctxGravacao, alerta, canais, errEntrega); err != nil {
+			logger.Error("Erro ao registar alerta de compliance", zap.Error(err))
+		}
+	}
+}
+
+// novoIDExecucao gera o UUID (v4) que agrupa os sumários das regiões de uma execução agendada
+func novoIDExecucao() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("erro ao gerar ID da execução: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/alerts"
+	"innovabiz/iam/identity-service/internal/infrastructure/notification"
+)
+
+// canalEmail envia as notificações em texto simples para os destinatários configurados
+type canalEmail struct {
+	sender        *notification.SMTPSender
+	destinatarios []string
+}
+
+// Name retorna o nome do canal registado com o alerta
+func (c *canalEmail) Name() string { return "email" }
+
+// Send envia a notificação a cada destinatário e junta os erros das entregas falhadas
+func (c *canalEmail) Send(ctx context.Context, notificacao alerts.Notification) error {
+	assunto := fmt.Sprintf("[Compliance] %d alerta(s) na região %s", len(notificacao.Alerts), notificacao.Region)
+
+	var corpo strings.Builder
+	fmt.Fprintf(&corpo, "Execução %s da região %s\r\n\r\n", notificacao.RunID, notificacao.Region)
+	for _, alerta := range notificacao.Alerts {
+		fmt.Fprintf(&corpo, "- %s\r\n", alerta.Message)
+	}
+	if t := notificacao.Trend; t != nil {
+		fmt.Fprintf(&corpo, "\r\nPontuação geral: %.1f%%", t.Score)
+		if t.Previous != nil {
+			fmt.Fprintf(&corpo, " (anterior %.1f%%, variação %+.1f)", *t.Previous, t.Delta)
+		}
+		fmt.Fprintf(&corpo, "\r\nMédia das últimas %d execuções: %.1f%% (tendência %+.2f por execução)\r\n", t.Runs, t.Average, t.Slope)
+	}
+
+	var errs []error
+	for _, destinatario := range c.destinatarios {
+		if err := c.sender.Send(ctx, destinatario, assunto, corpo.String()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", destinatario, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/alerts"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// registoExecucoes grava os sumários das execuções e os alertas enviados no Postgres
+// As tabelas são criadas pelas migrações 000015_compliance_runs e 000023_compliance_run_failures
+// do Identity Service
+type registoExecucoes struct {
+	pool *pgxpool.Pool
+}
+
+// abrirRegistoExecucoes liga ao Postgres e confirma a ligação
+func abrirRegistoExecucoes(ctx context.Context, databaseURL string) (*registoExecucoes, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao configurar ligação ao Postgres: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("erro ao ligar ao Postgres: %w", err)
+	}
+	return &registoExecucoes{pool: pool}, nil
+}
+
+// Close fecha as ligações ao Postgres
+func (r *registoExecucoes) Close() {
+	r.pool.Close()
+}
+
+// Save acrescenta o sumário de uma região ao histórico
+func (r *registoExecucoes) Save(ctx context.Context, execucao history.Run) error {
+	frameworkScores, err := json.Marshal(execucao.FrameworkScores)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar pontuações por framework: %w", err)
+	}
+	falhas, err := json.Marshal(execucao.Failures)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar testes reprovados: %w", err)
+	}
+
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO iam.compliance_runs (
+			run_id, region, region_name, target, policy_revision, total_tests, passed_tests, failed_tests,
+			compliance_score, framework_scores, requirements_failed, critical_requirements_failed,
+			failed_tests, executed_at, duration_ms
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		execucao.RunID, execucao.Region, execucao.RegionName, execucao.Target, execucao.PolicyRevision,
+		execucao.TotalTests, execucao.PassedTests, execucao.FailedTests, execucao.ComplianceScore,
+		frameworkScores, execucao.RequirementsFailed, execucao.CriticalRequirementsFailed,
+		falhas, execucao.ExecutedAt, execucao.Duration)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar execução de compliance da região %s: %w", execucao.Region, err)
+	}
+	return nil
+}
+
+// History retorna as últimas execuções da região, da mais recente para a mais antiga
+func (r *registoExecucoes) History(ctx context.Context, region string, limite int) ([]history.Run, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT run_id::text, region, COALESCE(region_name, ''), target, COALESCE(policy_revision, ''),
+			total_tests, passed_tests, failed_tests, compliance_score, framework_scores,
+			requirements_failed, critical_requirements_failed, failed_tests, executed_at, duration_ms
+		FROM iam.compliance_runs
+		WHERE region = $1
+		ORDER BY executed_at DESC
+		LIMIT $2`, region, limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar histórico de compliance da região %s: %w", region, err)
+	}
+	defer rows.Close()
+
+	var execucoes []history.Run
+	for rows.Next() {
+		var execucao history.Run
+		var frameworkScores, falhas []byte
+		if err := rows.Scan(&execucao.RunID, &execucao.Region, &execucao.RegionName, &execucao.Target,
+			&execucao.PolicyRevision, &execucao.TotalTests, &execucao.PassedTests, &execucao.FailedTests,
+			&execucao.ComplianceScore, &frameworkScores, &execucao.RequirementsFailed,
+			&execucao.CriticalRequirementsFailed, &falhas, &execucao.ExecutedAt, &execucao.Duration); err != nil {
+			return nil, fmt.Errorf("erro ao ler histórico de compliance: %w", err)
+		}
+		if err := json.Unmarshal(frameworkScores, &execucao.FrameworkScores); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar pontuações por framework: %w", err)
+		}
+		if err := json.Unmarshal(falhas, &execucao.Failures); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar testes reprovados: %w", err)
+		}
+		execucoes = append(execucoes, execucao)
+	}
+	return execucoes, rows.Err()
+}
+
+// Regions retorna as regiões com execuções gravadas
+func (r *registoExecucoes) Regions(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT DISTINCT region FROM iam.compliance_runs ORDER BY region`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar regiões do histórico de compliance: %w", err)
+	}
+	defer rows.Close()
+
+	var regioes []string
+	for rows.Next() {
+		var region string
+		if err := rows.Scan(&region); err != nil {
+			return nil, fmt.Errorf("erro ao ler regiões do histórico de compliance: %w", err)
+		}
+		regioes = append(regioes, region)
+	}
+	return regioes, rows.Err()
+}
+
+// gravarAlerta regista um alerta e os canais para os quais foi entregue
+func (r *registoExecucoes) gravarAlerta(ctx context.Context, alerta alerts.Alert, canais []string, erroEntrega error) error {
+	var deliveryError *string
+	if erroEntrega != nil {
+		msg := erroEntrega.Error()
+		deliveryError = &msg
+	}
+	if canais == nil {
+		canais = []string{}
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO iam.compliance_alerts (
+			run_id, region, kind, framework_id, requirement_id, score, previous_score, threshold,
+			message, channels, delivery_error
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11)`,
+		alerta.RunID, alerta.Region, alerta.Kind, alerta.FrameworkID, alerta.RequirementID,
+		alerta.Score, alerta.PreviousScore, alerta.Threshold, alerta.Message, canais, deliveryError)
+	if err != nil {
+		return fmt.Errorf("erro ao registar alerta de compliance: %w", err)
+	}
+	return nil
+}
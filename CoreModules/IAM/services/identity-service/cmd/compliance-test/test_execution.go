@@ -10,13 +10,17 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/decisionlog"
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/pdp"
 	"github.com/olekukonko/tablewriter"
+	"github.com/open-policy-agent/opa/metrics"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown"
 	"go.uber.org/zap"
 )
 
 // executarTeste executa um caso de teste específico contra a política OPA
-func executarTeste(logger *zap.Logger, opaPath string, testCase TestCase, reqToFramework map[string]string, reqToCriticality map[string]string) (*TestResult, error) {
+func executarTeste(logger *zap.Logger, env *ambientePoliticas, testCase TestCase, reqToFramework map[string]string, reqToCriticality map[string]string) (*TestResult, error) {
 	// Prepara o resultado do teste
 	result := &TestResult{
 		TestCase:     testCase,
@@ -31,22 +35,9 @@ func executarTeste(logger *zap.Logger, opaPath string, testCase TestCase, reqToF
 		result.ComplianceRegion = region
 	}
 	
-	// Mapeia requisitos para frameworks
-	for _, reqID := range testCase.RequirementIDs {
-		if frameworkID, ok := reqToFramework[reqID]; ok {
-			if !contains(result.Frameworks, frameworkID) {
-				result.Frameworks = append(result.Frameworks, frameworkID)
-			}
-		}
-		
-		// Define criticality baseado no requisito de maior criticidade
-		if criticality, ok := reqToCriticality[reqID]; ok {
-			if result.Criticality == "" || criticality == "alta" || 
-				(criticality == "média" && result.Criticality == "baixa") {
-				result.Criticality = criticality
-			}
-		}
-	}
+	// Mapeia requisitos para frameworks e define a criticidade pelo requisito mais crítico
+	result.Frameworks = frameworksCasoTeste(testCase, reqToFramework)
+	result.Criticality = criticidadeCasoTeste(testCase, reqToCriticality)
 	
 	// Mede o tempo de execução
 	startTime := time.Now()
@@ -54,15 +45,6 @@ func executarTeste(logger *zap.Logger, opaPath string, testCase TestCase, reqToF
 	// Prepara a consulta Rego
 	ctx := context.Background()
 	
-	// Forma o caminho da política completo
-	fullPolicyPath := filepath.Join(opaPath, testCase.PolicyPath)
-	
-	// Executa a consulta usando o OPA
-	r := rego.New(
-		rego.Query("data."+strings.ReplaceAll(testCase.PolicyPath, "/", ".")),
-		rego.Load([]string{fullPolicyPath}, nil),
-	)
-	
 	// Prepara o input do teste
 	inputBytes, err := json.Marshal(testCase.Input)
 	if err != nil {
@@ -74,11 +56,35 @@ func executarTeste(logger *zap.Logger, opaPath string, testCase TestCase, reqToF
 		return nil, fmt.Errorf("erro ao preparar input: %w", err)
 	}
 	
+	// Com --target pdp, a decisão é pedida ao PDP em execução em vez de avaliada localmente
+	if env.pdp != nil {
+		return executarTestePDP(ctx, logger, env, result, input, startTime)
+	}
+	
+	// Executa a consulta usando o OPA, a partir do bundle ou do arquivo de política
+	options := append([]func(*rego.Rego){
+		rego.Query(decisionlog.Query(testCase.PolicyPath)),
+	}, env.opcoesRego(testCase)...)
+	
+	// Com o log de decisões ativo, recolhe as métricas de compilação e avaliação da consulta
+	evalOptions := options
+	var m metrics.Metrics
+	if env.decisoes != nil {
+		m = metrics.New()
+		evalOptions = append(append([]func(*rego.Rego){}, options...), rego.Metrics(m))
+	}
+	r := rego.New(evalOptions...)
+	
 	// Executa a consulta com o input
 	rs, err := r.Eval(ctx, rego.EvalInput(input))
 	if err != nil {
 		result.Passed = false
 		result.Message = fmt.Sprintf("Erro ao avaliar política: %v", err)
+		result.EvaluationError = err
+		if m != nil {
+			result.Metrics = m.All()
+		}
+		result.DecisionTrace = tracarDecisao(ctx, options, input)
 		return result, nil
 	}
 	
@@ -91,55 +97,135 @@ func executarTeste(logger *zap.Logger, opaPath string, testCase TestCase, reqToF
 	// Registra o resultado e o tempo
 	result.ActualDecision = decision
 	result.ExecutionTimeMs = time.Since(startTime).Milliseconds()
+	if m != nil {
+		result.Metrics = m.All()
+	}
 	
 	// Compara com o resultado esperado
-	expectedBytes, err := json.Marshal(testCase.ExpectedDecision)
+	if err := compararDecisao(result, decision); err != nil {
+		return nil, err
+	}
+	if !result.Passed {
+		result.Message = fmt.Sprintf("Resultado esperado não corresponde ao resultado atual")
+		
+		// Reavalia com tracer apenas nas falhas, para não penalizar os testes que passam
+		result.DecisionTrace = tracarDecisao(ctx, options, input)
+	}
+	
+	registarResultadoTeste(logger, result)
+	return result, nil
+}
+
+// executarTestePDP pede a decisão do caso de teste à API de decisões do PDP e compara-a com a esperada
+// Não há trace da decisão: o PDP remoto não o expõe, pelo que as falhas indicam o ID da decisão no PDP
+func executarTestePDP(ctx context.Context, logger *zap.Logger, env *ambientePoliticas, result *TestResult, input interface{}, startTime time.Time) (*TestResult, error) {
+	testCase := result.TestCase
+	if len(testCase.DataFiles) > 0 {
+		logger.Warn("Documentos de dados do caso de teste ignorados na avaliação no PDP",
+			zap.String("testId", testCase.ID),
+			zap.Strings("dataFiles", testCase.DataFiles))
+	}
+	
+	decisao, err := env.pdp.Evaluate(ctx, testCase.PolicyPath, input)
+	result.ExecutionTimeMs = time.Since(startTime).Milliseconds()
+	if err != nil {
+		result.Passed = false
+		result.Message = fmt.Sprintf("Erro ao obter decisão do PDP: %v", err)
+		result.EvaluationError = err
+		registarResultadoTeste(logger, result)
+		return result, nil
+	}
+	
+	result.ActualDecision = decisao.Result
+	result.PDPDecisionID = decisao.DecisionID
+	if err := compararDecisao(result, decisao.Result); err != nil {
+		return nil, err
+	}
+	if !result.Passed {
+		if decisao.Defined {
+			result.Message = "Decisão do PDP não corresponde ao resultado esperado"
+		} else {
+			result.Message = "Decisão indefinida no PDP: a política não existe no bundle ativo ou não produziu resultado"
+		}
+		if decisao.DecisionID != "" {
+			result.Message += fmt.Sprintf(" (decision_id %s)", decisao.DecisionID)
+		}
+	}
+	
+	registarResultadoTeste(logger, result)
+	return result, nil
+}
+
+// compararDecisao compara a decisão obtida com a esperada pela sua serialização JSON
+// e, quando diferem, extrai os códigos das violações reportadas pela política
+func compararDecisao(result *TestResult, decision interface{}) error {
+	expectedBytes, err := json.Marshal(result.TestCase.ExpectedDecision)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar decisão esperada: %w", err)
+		return fmt.Errorf("erro ao serializar decisão esperada: %w", err)
 	}
 	
 	actualBytes, err := json.Marshal(decision)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao serializar decisão atual: %w", err)
+		return fmt.Errorf("erro ao serializar decisão atual: %w", err)
 	}
 	
-	// Compara resultados
 	result.Passed = string(expectedBytes) == string(actualBytes)
-	if !result.Passed {
-		result.Message = fmt.Sprintf("Resultado esperado não corresponde ao resultado atual")
-		
-		// Extrai violações se existirem
-		if actual, ok := decision.(map[string]interface{}); ok {
-			if violations, ok := actual["violations"].([]interface{}); ok {
-				for _, v := range violations {
-					if violation, ok := v.(map[string]interface{}); ok {
-						if code, ok := violation["code"].(string); ok {
-							result.Violations = append(result.Violations, code)
-						}
+	if result.Passed {
+		return nil
+	}
+	
+	// Extrai violações se existirem
+	if actual, ok := decision.(map[string]interface{}); ok {
+		if violations, ok := actual["violations"].([]interface{}); ok {
+			for _, v := range violations {
+				if violation, ok := v.(map[string]interface{}); ok {
+					if code, ok := violation["code"].(string); ok {
+						result.Violations = append(result.Violations, code)
 					}
 				}
 			}
 		}
 	}
-	
-	// Log do resultado do teste
+	return nil
+}
+
+// registarResultadoTeste regista o resultado do teste no log da execução
+func registarResultadoTeste(logger *zap.Logger, result *TestResult) {
 	logLevel := zap.InfoLevel
 	if !result.Passed {
 		logLevel = zap.WarnLevel
 	}
 	
 	logger.Log(logLevel, "Resultado do teste",
-		zap.String("testId", testCase.ID),
-		zap.String("name", testCase.Name),
+		zap.String("testId", result.TestCase.ID),
+		zap.String("name", result.TestCase.Name),
 		zap.Bool("passed", result.Passed),
 		zap.String("criticality", result.Criticality),
 		zap.Int64("executionTimeMs", result.ExecutionTimeMs))
-	
-	return result, nil
+}
+
+// tracarDecisao reavalia a consulta com um tracer e retorna o trace formatado da decisão OPA,
+// truncado em maxTraceLines linhas para manter o relatório legível
+func tracarDecisao(ctx context.Context, options []func(*rego.Rego), input interface{}) string {
+	buf := topdown.NewBufferTracer()
+	tracedOptions := append(append([]func(*rego.Rego){}, options...), rego.QueryTracer(buf))
+	
+	// O erro de avaliação já foi registado no resultado; o trace parcial continua útil
+	_, _ = rego.New(tracedOptions...).Eval(ctx, rego.EvalInput(input))
+	
+	var sb strings.Builder
+	topdown.PrettyTraceWithLocation(&sb, *buf)
+	
+	lines := strings.Split(strings.TrimRight(sb.String(), "\n"), "\n")
+	if len(lines) > maxTraceLines {
+		omitted := len(lines) - maxTraceLines
+		lines = append(lines[:maxTraceLines], fmt.Sprintf("... (%d linhas omitidas)", omitted))
+	}
+	return strings.Join(lines, "\n")
 }
 
 // gerarRelatorios gera os relatórios de conformidade em diferentes formatos
-func gerarRelatorios(logger *zap.Logger, config Config, summary *TestSummary) {
+func gerarRelatorios(logger *zap.Logger, config Config, matrix *ComplianceMatrix, summary *TestSummary) {
 	// Cria diretório de saída se não existir
 	outputDir := filepath.Join(config.OutputDir, summary.Region)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -149,10 +235,22 @@ func gerarRelatorios(logger *zap.Logger, config Config, summary *TestSummary) {
 		return
 	}
 	
+	timestamp := time.Now().Format("20060102_150405")
+	
+	// Carrega as execuções anteriores antes de gravar o relatório JSON atual
+	var history []*TestSummary
+	if config.HTML {
+		var err error
+		history, err = carregarHistoricoRelatorios(outputDir, summary.Region, config.HistoryRuns)
+		if err != nil {
+			logger.Warn("Erro ao carregar histórico de relatórios", zap.Error(err))
+		}
+	}
+	
 	// Gera relatório JSON
 	if config.Json {
 		jsonPath := filepath.Join(outputDir, fmt.Sprintf("compliance_report_%s_%s.json", 
-			summary.Region, time.Now().Format("20060102_150405")))
+			summary.Region, timestamp))
 		
 		jsonData, err := json.MarshalIndent(summary, "", "  ")
 		if err != nil {
@@ -166,355 +264,24 @@ func gerarRelatorios(logger *zap.Logger, config Config, summary *TestSummary) {
 		}
 	}
 	
-	// Gera relatório HTML
+	// Gera relatório HTML autocontido
 	if config.HTML {
 		htmlPath := filepath.Join(outputDir, fmt.Sprintf("compliance_report_%s_%s.html", 
-			summary.Region, time.Now().Format("20060102_150405")))
-		
-		// Modelo HTML para relatório
-		html := fmt.Sprintf(`
-<!DOCTYPE html>
-<html lang="pt-PT">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Relatório de Conformidade - %s</title>
-    <style>
-        body {
-            font-family: 'Segoe UI', Arial, sans-serif;
-            margin: 20px;
-            color: #333;
-            line-height: 1.6;
-        }
-        header {
-            background-color: #0056b3;
-            color: white;
-            padding: 20px;
-            border-radius: 5px;
-            margin-bottom: 20px;
-        }
-        h1 {
-            margin: 0;
-            font-weight: 600;
-        }
-        h2 {
-            color: #0056b3;
-            border-bottom: 1px solid #ddd;
-            padding-bottom: 10px;
-            margin-top: 30px;
-        }
-        .summary {
-            background-color: #f9f9f9;
-            padding: 20px;
-            border-radius: 5px;
-            margin-bottom: 30px;
-            border-left: 5px solid #0056b3;
-        }
-        .summary-row {
-            display: flex;
-            justify-content: space-between;
-            margin-bottom: 10px;
-            border-bottom: 1px dotted #ddd;
-            padding-bottom: 5px;
-        }
-        .summary-label {
-            font-weight: 600;
-            flex: 1;
-        }
-        .summary-value {
-            flex: 2;
-            text-align: right;
-        }
-        .passed {
-            color: #28a745;
-            font-weight: bold;
-        }
-        .failed {
-            color: #dc3545;
-            font-weight: bold;
-        }
-        .warning {
-            color: #ffc107;
-            font-weight: bold;
-        }
-        table {
-            width: 100%%;
-            border-collapse: collapse;
-            margin-bottom: 30px;
-            box-shadow: 0 0 20px rgba(0,0,0,0.05);
-        }
-        th {
-            background-color: #0056b3;
-            color: white;
-            padding: 12px;
-            text-align: left;
-        }
-        td {
-            padding: 12px;
-            border-bottom: 1px solid #ddd;
-        }
-        tr:nth-child(even) {
-            background-color: #f9f9f9;
-        }
-        tr:hover {
-            background-color: #f1f1f1;
-        }
-        .compliance-gauge {
-            width: 100%%;
-            background-color: #f0f0f0;
-            border-radius: 5px;
-            margin: 10px 0;
-            height: 30px;
-            position: relative;
-        }
-        .compliance-gauge-fill {
-            height: 100%%;
-            border-radius: 5px;
-            background-color: #28a745;
-            width: %f%%;
-            position: absolute;
-            left: 0;
-            top: 0;
-        }
-        .compliance-gauge-text {
-            position: absolute;
-            width: 100%%;
-            text-align: center;
-            top: 50%%;
-            transform: translateY(-50%%);
-            font-weight: bold;
-            color: white;
-            text-shadow: 1px 1px 3px rgba(0,0,0,0.5);
-            z-index: 1;
-        }
-        .framework {
-            margin-bottom: 30px;
-            padding: 15px;
-            border-radius: 5px;
-            background-color: #f9f9f9;
-            border-left: 5px solid #0056b3;
-        }
-        .footer {
-            text-align: center;
-            margin-top: 50px;
-            color: #888;
-            font-size: 0.9em;
-            border-top: 1px solid #ddd;
-            padding-top: 20px;
-        }
-        .test-details {
-            margin-top: 30px;
-        }
-        .test-item {
-            border: 1px solid #ddd;
-            margin-bottom: 15px;
-            border-radius: 5px;
-            overflow: hidden;
-        }
-        .test-header {
-            padding: 10px;
-            font-weight: bold;
-            display: flex;
-            justify-content: space-between;
-            background-color: #f0f0f0;
-        }
-        .test-content {
-            padding: 15px;
-        }
-        .test-passed .test-header {
-            background-color: #d4edda;
-            color: #155724;
-        }
-        .test-failed .test-header {
-            background-color: #f8d7da;
-            color: #721c24;
-        }
-        .criticality-alta {
-            color: #dc3545;
-        }
-        .criticality-media {
-            color: #fd7e14;
-        }
-        .criticality-baixa {
-            color: #6c757d;
-        }
-    </style>
-</head>
-<body>
-    <header>
-        <h1>Relatório de Conformidade Regulatória</h1>
-        <p>%s (%s)</p>
-    </header>
-
-    <div class="summary">
-        <h2>Resumo de Conformidade</h2>
-        <div class="summary-row">
-            <span class="summary-label">Data de Execução:</span>
-            <span class="summary-value">%s</span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Pontuação de Conformidade:</span>
-            <span class="summary-value">%.2f%%</span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Total de Testes:</span>
-            <span class="summary-value">%d</span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Testes com Sucesso:</span>
-            <span class="summary-value"><span class="passed">%d</span></span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Testes Falhados:</span>
-            <span class="summary-value"><span class="failed">%d</span></span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Tempo de Execução:</span>
-            <span class="summary-value">%d ms</span>
-        </div>
-        <div class="compliance-gauge">
-            <div class="compliance-gauge-fill" style="width: %.2f%%;"></div>
-            <div class="compliance-gauge-text">%.2f%%</div>
-        </div>
-    </div>
-`, 
-    summary.RegionName, 
-    summary.ComplianceScore,
-    summary.RegionName, summary.Region, 
-    summary.ExecutedAt.Format("02/01/2006 15:04:05"), 
-    summary.ComplianceScore, 
-    summary.TotalTests, 
-    summary.PassedTests, 
-    summary.FailedTests, 
-    summary.Duration,
-    summary.ComplianceScore,
-    summary.ComplianceScore)
-		
-		// Adiciona seção de frameworks
-		html += `
-    <h2>Conformidade por Framework Regulatório</h2>
-`
-
-		for _, fs := range summary.FrameworkScores {
-			scoreClass := "passed"
-			if fs.ComplianceScore < 70 {
-				scoreClass = "failed"
-			} else if fs.ComplianceScore < 90 {
-				scoreClass = "warning"
-			}
-			
-			html += fmt.Sprintf(`
-    <div class="framework">
-        <h3>%s</h3>
-        <div class="summary-row">
-            <span class="summary-label">Pontuação de Conformidade:</span>
-            <span class="summary-value"><span class="%s">%.2f%%</span></span>
-        </div>
-        <div class="summary-row">
-            <span class="summary-label">Testes Passados:</span>
-            <span class="summary-value">%d de %d</span>
-        </div>
-        <div class="compliance-gauge">
-            <div class="compliance-gauge-fill" style="width: %.2f%%;"></div>
-            <div class="compliance-gauge-text">%.2f%%</div>
-        </div>
-    </div>
-`, 
-            fs.Name, 
-            scoreClass,
-            fs.ComplianceScore, 
-            fs.PassedTests, fs.TotalTests,
-            fs.ComplianceScore,
-            fs.ComplianceScore)
-		}
-		
-		// Adiciona detalhes dos testes
-		html += `
-    <h2>Detalhes dos Testes</h2>
-    <div class="test-details">
-`
+			summary.Region, timestamp))
 		
-		// Adiciona cada resultado de teste
-		for _, tr := range summary.TestResults {
-			testClass := "test-passed"
-			testStatus := "Passou"
-			if !tr.Passed {
-				testClass = "test-failed"
-				testStatus = "Falhou"
-			}
-			
-			criticality := "criticality-baixa"
-			if tr.Criticality == "alta" {
-				criticality = "criticality-alta"
-			} else if tr.Criticality == "média" || tr.Criticality == "media" {
-				criticality = "criticality-media"
-			}
-			
-			html += fmt.Sprintf(`
-        <div class="test-item %s">
-            <div class="test-header">
-                <span>%s - %s</span>
-                <span>%s</span>
-            </div>
-            <div class="test-content">
-                <p>%s</p>
-                <p><strong>ID:</strong> %s</p>
-                <p><strong>Criticidade:</strong> <span class="%s">%s</span></p>
-                <p><strong>Tempo de Execução:</strong> %d ms</p>
-`,
-                testClass,
-                tr.TestCase.ID,
-                tr.TestCase.Name,
-                testStatus,
-                tr.TestCase.Description,
-                tr.TestCase.ID,
-                criticality,
-                tr.Criticality,
-                tr.ExecutionTimeMs)
-                
-            if !tr.Passed && tr.Message != "" {
-                html += fmt.Sprintf(`
-                <p><strong>Mensagem:</strong> %s</p>
-`, tr.Message)
-            }
-            
-            if len(tr.Violations) > 0 {
-                html += `
-                <p><strong>Violações:</strong></p>
-                <ul>
-`
-                for _, v := range tr.Violations {
-                    html += fmt.Sprintf(`
-                    <li>%s</li>
-`, v)
-                }
-                html += `
-                </ul>
-`
-            }
-            
-            html += `
-            </div>
-        </div>
-`
+		htmlData, err := gerarRelatorioHTML(matrix, summary, history)
+		if err != nil {
+			logger.Error("Erro ao gerar relatório HTML", zap.Error(err))
+			return
 		}
 		
-		html += `
-    </div>
-
-    <div class="footer">
-        <p>Gerado por INNOVABIZ IAM Compliance Testing Framework</p>
-        <p>© 2025 INNOVABIZ - Todos os direitos reservados</p>
-    </div>
-</body>
-</html>
-`
-		
 		// Salva o arquivo HTML
-		if err := os.WriteFile(htmlPath, []byte(html), 0644); err != nil {
+		if err := os.WriteFile(htmlPath, htmlData, 0644); err != nil {
 			logger.Error("Erro ao salvar relatório HTML", zap.Error(err))
 		} else {
-			logger.Info("Relatório HTML gerado com sucesso", zap.String("path", htmlPath))
+			logger.Info("Relatório HTML gerado com sucesso",
+				zap.String("path", htmlPath),
+				zap.Int("historyRuns", len(history)))
 		}
 	}
 }
@@ -530,12 +297,22 @@ func exibirSumarioConsole(summary *TestSummary) {
 	fmt.Printf("   %s Testes aprovados: %s\n", 
 		color.WhiteString("•"), 
 		color.GreenString("%d", summary.PassedTests))
+	reprovados := color.GreenString("%d", summary.FailedTests)
+	if summary.FailedTests > 0 {
+		reprovados = color.RedString("%d", summary.FailedTests)
+	}
 	fmt.Printf("   %s Testes reprovados: %s\n", 
 		color.WhiteString("•"), 
-		summary.FailedTests > 0 ? color.RedString("%d", summary.FailedTests) : color.GreenString("%d", summary.FailedTests))
+		reprovados)
 	fmt.Printf("   %s Pontuação de compliance: %s\n", 
 		color.WhiteString("•"), 
 		formatComplianceScore(summary.ComplianceScore))
+	if summary.PDPURL != "" {
+		fmt.Printf("   %s PDP avaliado: %s (bundles: %s)\n",
+			color.WhiteString("•"),
+			summary.PDPURL,
+			pdp.FormatRevisions(summary.PDPRevisions))
+	}
 
 	fmt.Println("\nResultados por Framework:")
 	for _, frameworkScore := range summary.FrameworkScores {
@@ -730,7 +507,10 @@ func parseFlags() Config {
 	outputDir := flag.String("output", "./reports", "Diretório para salvar relatórios")
 	regionStr := flag.String("regions", "AO", "Regiões a testar (separadas por vírgula)")
 	frameworkStr := flag.String("frameworks", "", "Frameworks a testar (separados por vírgula)")
-	tagStr := flag.String("tags", "", "Tags a filtrar (separadas por vírgula)")
+	tagStr := flag.String("tags", "", "Expressão de tags a selecionar (ex: \"gdpr && !slow || criticality:alta\"; vírgula equivale a ||)")
+	excludeStr := flag.String("exclude-file", "", "Arquivos com IDs ou padrões de casos de teste a excluir (separados por vírgula)")
+	shard := flag.String("shard", "", "Shard a executar no formato K/N, para particionar a suíte entre jobs de CI")
+	listOnly := flag.Bool("list-only", false, "Listar os casos de teste selecionados sem executá-los")
 	format := flag.String("format", "table", "Formato do relatório (table, json, html)")
 	verbose := flag.Bool("verbose", false, "Modo verboso")
 	summary := flag.Bool("summary", true, "Exibir sumário no console")
@@ -748,6 +528,37 @@ func parseFlags() Config {
 	noApproval := flag.Bool("no-approval", false, "Não solicitar aprovação antes de aplicar remediações")
 	maxRemedPerPolicy := flag.Int("max-remed-per-policy", 5, "Número máximo de remediações por arquivo de política")
 	
+	// Configuração de bundles OPA
+	bundlePath := flag.String("bundle", "", "Bundle OPA (diretório ou .tar.gz) a avaliar em vez de --opa")
+	bundleVerifyKey := flag.String("bundle-verify-key", "", "Arquivo com a chave pública ou segredo para verificar a assinatura do bundle")
+	bundleVerifyKeyID := flag.String("bundle-verify-key-id", "", "ID da chave de verificação (padrão: default)")
+	bundleVerifyAlg := flag.String("bundle-verify-alg", "", "Algoritmo de assinatura do bundle (padrão: RS256 para PEM, HS256 para segredos)")
+	bundleVerifyScope := flag.String("bundle-verify-scope", "", "Escopo esperado na assinatura do bundle")
+	bundleSkipVerify := flag.Bool("bundle-skip-verify", false, "Não verificar a assinatura do bundle")
+	dataStr := flag.String("data", "", "Documentos de dados e políticas auxiliares adicionais (separados por vírgula)")
+	
+	// Configuração do relatório HTML
+	historyRuns := flag.Int("history-runs", defaultHistoryRuns, "Número de execuções anteriores no gráfico de evolução do relatório HTML")
+	
+	// Log de decisões OPA
+	decisionLog := flag.String("decision-log", "", "Arquivo NDJSON onde registar a decisão OPA de cada teste (input, política, resultado e métricas)")
+	
+	// Alvo de execução dos casos de teste
+	target := flag.String("target", alvoLocal, "Alvo de execução: local (políticas Rego ou bundle) ou pdp (API de decisões de um PDP em execução)")
+	pdpURL := flag.String("pdp-url", "", "URL base do PDP, usada com --target pdp (ex: http://opa:8181)")
+	pdpToken := flag.String("pdp-token", os.Getenv("PDP_TOKEN"), "Token bearer para a API do PDP (padrão: variável PDP_TOKEN)")
+	pdpTimeout := flag.Duration("pdp-timeout", pdp.DefaultTimeout, "Tempo máximo de espera por cada decisão do PDP")
+	
+	// Histórico das execuções
+	historyDB := flag.String("history-db", os.Getenv("COMPLIANCE_HISTORY_DB"), "Histórico onde gravar o sumário de cada região: arquivo SQLite ou URL postgres:// (padrão: variável COMPLIANCE_HISTORY_DB)")
+	
+	// Sumário para pull requests e anotações do GitHub Actions
+	summaryFormat := flag.String("report-format", "", "Sumário adicional para comentários de pull request (markdown)")
+	summaryPath := flag.String("report-output", "", "Arquivo do sumário de --report-format, ou - para a saída padrão (padrão: <output>/"+sumarioMarkdownPadrao+")")
+	baselineDir := flag.String("baseline", "", "Diretório de relatórios JSON de referência para os deltas (padrão: execução anterior em --output)")
+	requirementURL := flag.String("requirement-url", "", "Modelo de ligação dos IDs de requisito, com os marcadores {id} e {region}")
+	githubAnnotations := flag.Bool("github-annotations", false, "Emitir anotações do GitHub Actions para os testes reprovados")
+	
 	flag.Parse()
 	
 	// Configuração base
@@ -761,6 +572,9 @@ func parseFlags() Config {
 		ShowSummary:  *summary,
 		Json:         *json,
 		HTML:         *html,
+		Tags:         *tagStr,
+		Shard:        *shard,
+		ListOnly:     *listOnly,
 		
 		// Configuração de remediação
 		Remediate:                *remediate,
@@ -771,6 +585,36 @@ func parseFlags() Config {
 		BackupDir:                *backupDir,
 		RequireApproval:          !*noApproval,
 		MaxRemediationsPerPolicy: *maxRemedPerPolicy,
+		
+		// Configuração de bundles OPA
+		BundlePath:        *bundlePath,
+		BundleVerifyKey:   *bundleVerifyKey,
+		BundleVerifyKeyID: *bundleVerifyKeyID,
+		BundleVerifyAlg:   *bundleVerifyAlg,
+		BundleVerifyScope: *bundleVerifyScope,
+		BundleSkipVerify:  *bundleSkipVerify,
+		
+		// Configuração do relatório HTML
+		HistoryRuns: *historyRuns,
+		
+		// Log de decisões OPA
+		DecisionLogPath: *decisionLog,
+		
+		// Alvo de execução
+		Target:     *target,
+		PDPURL:     *pdpURL,
+		PDPToken:   *pdpToken,
+		PDPTimeout: *pdpTimeout,
+		
+		// Histórico das execuções
+		HistoryDB: *historyDB,
+		
+		// Sumário para pull requests e anotações do GitHub Actions
+		SummaryFormat:     *summaryFormat,
+		SummaryPath:       *summaryPath,
+		BaselineDir:       *baselineDir,
+		RequirementURL:    *requirementURL,
+		GitHubAnnotations: *githubAnnotations,
 	}
 	
 	// Processar strings separadas por vírgulas
@@ -778,13 +622,17 @@ func parseFlags() Config {
 		config.Frameworks = strings.Split(*frameworkStr, ",")
 	}
 	
-	if *tagStr != "" {
-		config.Tags = strings.Split(*tagStr, ",")
+	if *excludeStr != "" {
+		config.ExcludeFiles = strings.Split(*excludeStr, ",")
 	}
 	
 	if *ignoreTypesStr != "" {
 		config.IgnoreTypes = strings.Split(*ignoreTypesStr, ",")
 	}
 	
+	if *dataStr != "" {
+		config.DataPaths = strings.Split(*dataStr, ",")
+	}
+	
 	return config
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/innovabiz/iam/services/identity-service/cmd/compliance-test/history"
+)
+
+// abrirRegistoTendencias abre o histórico indicado em --history-db: URLs postgres:// ou postgresql://
+// usam o Postgres do Identity Service; qualquer outro valor é o caminho de um arquivo SQLite,
+// opcionalmente com o prefixo sqlite:
+func abrirRegistoTendencias(ctx context.Context, destino string) (history.Store, error) {
+	if strings.HasPrefix(destino, "postgres://") || strings.HasPrefix(destino, "postgresql://") {
+		return abrirRegistoExecucoes(ctx, destino)
+	}
+	caminho := strings.TrimPrefix(strings.TrimPrefix(destino, "sqlite://"), "sqlite:")
+	return history.OpenSQLite(ctx, caminho)
+}
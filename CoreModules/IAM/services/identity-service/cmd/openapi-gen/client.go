@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"innovabiz/iam/identity-service/internal/interface/api/openapi"
+)
+
+// pathParamPattern identifica os segmentos {nome} dos caminhos
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// clientGenerator acumula o código gerado e os imports necessários
+type clientGenerator struct {
+	doc     *openapi.Document
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// generateClient gera o código do cliente tipado para o documento indicado
+func generateClient(doc *openapi.Document, packageName string) ([]byte, error) {
+	g := &clientGenerator{doc: doc, imports: map[string]bool{"context": true, "net/http": true}}
+
+	g.writeTypes()
+	g.writeOperations()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by openapi-gen a partir de api/openapi.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", packageName)
+	out.WriteString("import (\n")
+	var external []string
+	for _, imp := range sortedKeys(g.imports) {
+		if strings.Contains(imp, ".") {
+			external = append(external, imp)
+			continue
+		}
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	if len(external) > 0 {
+		out.WriteString("\n")
+		for _, imp := range external {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("falha ao formatar cliente gerado: %w", err)
+	}
+	return source, nil
+}
+
+// writeTypes gera uma estrutura por schema de componente
+func (g *clientGenerator) writeTypes() {
+	schemas := g.doc.Components.Schemas
+	for _, name := range sortedKeys(schemas) {
+		schema := schemas[name]
+		fmt.Fprintf(&g.buf, "// %s corresponde ao schema %s do documento OpenAPI\n", name, name)
+		fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+
+		required := make(map[string]bool)
+		for _, prop := range schema.Required {
+			required[prop] = true
+		}
+		for _, prop := range sortedKeys(schema.Properties) {
+			goType := g.goType(schema.Properties[prop], !required[prop])
+			tag := prop
+			if !required[prop] {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&g.buf, "\t%s %s `json:%q`\n", fieldName(prop), goType, tag)
+		}
+		g.buf.WriteString("}\n\n")
+	}
+}
+
+// writeOperations gera um método do cliente por operação, ordenado por caminho e método
+func (g *clientGenerator) writeOperations() {
+	for _, path := range sortedKeys(g.doc.Paths) {
+		item := g.doc.Paths[path]
+		for _, method := range openapi.Methods {
+			if op := item.Operation(method); op != nil && jsonOperation(op) {
+				g.writeOperation(path, method, op)
+			}
+		}
+	}
+}
+
+// writeOperation gera o método do cliente para uma operação
+func (g *clientGenerator) writeOperation(path, method string, op *openapi.Operation) {
+	name := exportName(op.OperationID)
+
+	var args []string
+	var queryParams []*openapi.Parameter
+	pathTypes := make(map[string]string)
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			goType := g.goType(param.Schema, false)
+			pathTypes[param.Name] = goType
+			args = append(args, varName(param.Name)+" "+goType)
+		case "query":
+			queryParams = append(queryParams, param)
+		}
+	}
+
+	if len(queryParams) > 0 {
+		g.writeParamsType(name, queryParams)
+		args = append(args, "params *"+name+"Params")
+	}
+
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		args = append(args, "body "+g.goType(op.RequestBody.Content["application/json"].Schema, false))
+		bodyArg = "body"
+	}
+
+	status, success := successResponse(op)
+	var result string
+	if success != nil && len(success.Content) > 0 {
+		result = g.goType(success.Content["application/json"].Schema, false)
+	}
+
+	// Assinatura
+	fmt.Fprintf(&g.buf, "// %s %s\n//\n// %s %s\n", name, lowerFirst(op.Summary), method, path)
+	signature := fmt.Sprintf("func (c *Client) %s(%s)", name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "))
+	switch {
+	case result == "":
+		fmt.Fprintf(&g.buf, "%s error {\n", signature)
+	case strings.HasPrefix(result, "[]") || result == "json.RawMessage":
+		fmt.Fprintf(&g.buf, "%s (%s, error) {\n", signature, result)
+	default:
+		fmt.Fprintf(&g.buf, "%s (*%s, error) {\n", signature, result)
+	}
+
+	// Caminho com parâmetros escapados
+	g.imports["net/url"] = true
+	fmt.Fprintf(&g.buf, "\tpath := %s\n", pathExpression(path, pathTypes))
+
+	// Parâmetros de query
+	queryArg := "nil"
+	if len(queryParams) > 0 {
+		g.imports["fmt"] = true
+		queryArg = "query"
+		g.buf.WriteString("\tquery := url.Values{}\n\tif params != nil {\n")
+		for _, param := range queryParams {
+			field := fieldName(param.Name)
+			fmt.Fprintf(&g.buf, "\t\tif params.%s != nil {\n\t\t\tquery.Set(%q, fmt.Sprint(*params.%s))\n\t\t}\n", field, param.Name, field)
+		}
+		g.buf.WriteString("\t}\n")
+	}
+
+	httpMethod := "http.Method" + exportName(strings.ToLower(method))
+	if result == "" {
+		fmt.Fprintf(&g.buf, "\treturn c.do(ctx, %s, path, %s, %s, nil, %s)\n}\n\n", httpMethod, queryArg, bodyArg, statusExpression(status))
+		return
+	}
+
+	fmt.Fprintf(&g.buf, "\tvar out %s\n", result)
+	fmt.Fprintf(&g.buf, "\tif err := c.do(ctx, %s, path, %s, %s, &out, %s); err != nil {\n", httpMethod, queryArg, bodyArg, statusExpression(status))
+	if strings.HasPrefix(result, "[]") || result == "json.RawMessage" {
+		g.buf.WriteString("\t\treturn nil, err\n\t}\n\treturn out, nil\n}\n\n")
+	} else {
+		g.buf.WriteString("\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n")
+	}
+}
+
+// writeParamsType gera a estrutura de parâmetros de query de uma operação
+func (g *clientGenerator) writeParamsType(name string, params []*openapi.Parameter) {
+	fmt.Fprintf(&g.buf, "// %sParams contém os parâmetros de query opcionais de %s\n", name, name)
+	fmt.Fprintf(&g.buf, "type %sParams struct {\n", name)
+	for _, param := range params {
+		if param.Description != "" {
+			fmt.Fprintf(&g.buf, "\t// %s\n", param.Description)
+		}
+		fmt.Fprintf(&g.buf, "\t%s *%s\n", fieldName(param.Name), g.goType(param.Schema, false))
+	}
+	g.buf.WriteString("}\n\n")
+}
+
+// goType converte um schema no tipo Go correspondente
+// Campos opcionais de estruturas, datas e UUIDs tornam-se ponteiros
+func (g *clientGenerator) goType(schema *openapi.Schema, optional bool) string {
+	pointer := ""
+	if optional {
+		pointer = "*"
+	}
+
+	switch {
+	case schema == nil:
+		return "interface{}"
+	case schema.Ref != "":
+		return pointer + openapi.RefName(schema)
+	case len(schema.OneOf) > 0:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return pointer + "time.Time"
+		case "uuid":
+			g.imports["github.com/google/uuid"] = true
+			return pointer + "uuid.UUID"
+		}
+		return "string"
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64"
+		}
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.goType(schema.Items, false)
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + g.goType(schema.AdditionalProperties, false)
+		}
+	}
+	return "interface{}"
+}
+
+// jsonOperation indica se a operação troca JSON e pode ter método no cliente
+// Os fluxos de navegador (formulários, redirecionamentos, XML) ficam de fora
+func jsonOperation(op *openapi.Operation) bool {
+	if op.RequestBody != nil {
+		if _, ok := op.RequestBody.Content["application/json"]; !ok {
+			return false
+		}
+	}
+	for code, response := range op.Responses {
+		var status int
+		if _, err := fmt.Sscanf(code, "%d", &status); err != nil {
+			continue
+		}
+		if status >= http.StatusMultipleChoices && status < http.StatusBadRequest {
+			return false
+		}
+		if _, ok := response.Content["application/json"]; len(response.Content) > 0 && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// successResponse retorna o código e a resposta de sucesso de uma operação
+func successResponse(op *openapi.Operation) (int, *openapi.Response) {
+	for _, code := range sortedKeys(op.Responses) {
+		var status int
+		if _, err := fmt.Sscanf(code, "%d", &status); err == nil && status < http.StatusMultipleChoices {
+			return status, op.Responses[code]
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// pathExpression monta a expressão Go que constrói o caminho com os parâmetros escapados
+func pathExpression(path string, pathTypes map[string]string) string {
+	var parts []string
+	last := 0
+	for _, loc := range pathParamPattern.FindAllStringSubmatchIndex(path, -1) {
+		if loc[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", path[last:loc[0]]))
+		}
+		name := path[loc[2]:loc[3]]
+		value := varName(name)
+		if pathTypes[name] != "string" {
+			value += ".String()"
+		}
+		parts = append(parts, "url.PathEscape("+value+")")
+		last = loc[1]
+	}
+	if last < len(path) {
+		parts = append(parts, fmt.Sprintf("%q", path[last:]))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// statusConstants associa os códigos de sucesso às constantes de net/http
+var statusConstants = map[int]string{
+	http.StatusOK:        "http.StatusOK",
+	http.StatusCreated:   "http.StatusCreated",
+	http.StatusAccepted:  "http.StatusAccepted",
+	http.StatusNoContent: "http.StatusNoContent",
+}
+
+// statusExpression retorna a expressão Go do código de estado esperado
+func statusExpression(status int) string {
+	if constant, ok := statusConstants[status]; ok {
+		return constant
+	}
+	return fmt.Sprint(status)
+}
+
+// varName converte o nome de um parâmetro de caminho em identificador Go (ex.: roleId -> roleID)
+func varName(name string) string {
+	if name == "id" {
+		return name
+	}
+	return lowerFirst(fieldName(name))
+}
+
+// fieldName converte o nome JSON no nome do campo Go, respeitando a sigla ID
+func fieldName(name string) string {
+	field := exportName(name)
+	if field == "Id" {
+		return "ID"
+	}
+	if strings.HasSuffix(field, "Id") {
+		return strings.TrimSuffix(field, "Id") + "ID"
+	}
+	return field
+}
+
+// exportName converte um identificador para a forma exportada
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// lowerFirst converte a primeira letra de uma frase para minúscula
+func lowerFirst(text string) string {
+	if text == "" {
+		return text
+	}
+	runes := []rune(text)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// sortedKeys retorna as chaves de um mapa em ordem alfabética
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+// Comando openapi-gen gera o documento OpenAPI do identity-service e o cliente Go tipado
+// a partir dele. Executado via go generate no pacote client.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"innovabiz/iam/identity-service/internal/interface/api/openapi"
+)
+
+func main() {
+	specPath := flag.String("spec", "api/openapi.json", "Caminho do documento OpenAPI gerado")
+	clientPath := flag.String("client", "client/client.gen.go", "Caminho do cliente Go gerado")
+	packageName := flag.String("package", "client", "Nome do pacote do cliente gerado")
+	flag.Parse()
+
+	if err := run(*specPath, *clientPath, *packageName); err != nil {
+		fmt.Fprintf(os.Stderr, "openapi-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run escreve o documento OpenAPI e gera o cliente a partir do documento escrito
+func run(specPath, clientPath, packageName string) error {
+	spec, err := json.MarshalIndent(openapi.Build(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("falha ao serializar documento OpenAPI: %w", err)
+	}
+	if err := writeFile(specPath, append(spec, '\n')); err != nil {
+		return err
+	}
+
+	// O cliente é gerado a partir do documento publicado e não dos tipos internos
+	var doc openapi.Document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("falha ao ler documento OpenAPI: %w", err)
+	}
+
+	source, err := generateClient(&doc, packageName)
+	if err != nil {
+		return err
+	}
+	return writeFile(clientPath, source)
+}
+
+// writeFile escreve o conteúdo criando o diretório quando necessário
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("falha ao criar diretório de %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("falha ao escrever %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a migração inicial: remove o esquema iam e todos os seus objetos
+ */
+
+-- As extensões são mantidas por poderem ser partilhadas com outros esquemas
+DROP SCHEMA IF EXISTS iam CASCADE;
@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte o histórico event-sourced de funções
+ */
+
+DROP TABLE IF EXISTS iam.role_snapshots;
+DROP TABLE IF EXISTS iam.role_events;
+DROP FUNCTION IF EXISTS iam.prevent_role_event_mutation();
@@ -0,0 +1,74 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Histórico event-sourced de funções (roles)
+ * Eventos append-only por função, com snapshots periódicos para reconstrução
+ * do estado em qualquer instante (auditoria point-in-time).
+ */
+
+-- Tabela de Eventos de Funções (append-only)
+CREATE TABLE iam.role_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    role_id UUID NOT NULL,
+    version BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    actor_id UUID,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_role_events_version UNIQUE(tenant_id, role_id, version),
+    CONSTRAINT ck_role_events_version CHECK (version > 0),
+    CONSTRAINT ck_role_events_type CHECK (event_type IN (
+        'ROLE_CREATED', 'ROLE_UPDATED', 'ROLE_DELETED',
+        'PERMISSIONS_ASSIGNED', 'PERMISSIONS_REVOKED',
+        'PARENT_ASSIGNED', 'PARENT_REMOVED'
+    ))
+);
+
+-- Sem chave estrangeira para iam.roles: o histórico sobrevive à exclusão física da função
+CREATE INDEX idx_role_events_role_time ON iam.role_events(tenant_id, role_id, occurred_at);
+
+COMMENT ON TABLE iam.role_events IS 'Histórico imutável de alterações de funções (event sourcing)';
+
+-- Bloquear alterações e exclusões de eventos já registrados
+CREATE OR REPLACE FUNCTION iam.prevent_role_event_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'iam.role_events é append-only: % não permitido', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_role_events_immutable
+BEFORE UPDATE OR DELETE ON iam.role_events
+FOR EACH ROW EXECUTE FUNCTION iam.prevent_role_event_mutation();
+
+CREATE TRIGGER trg_role_events_no_truncate
+BEFORE TRUNCATE ON iam.role_events
+FOR EACH STATEMENT EXECUTE FUNCTION iam.prevent_role_event_mutation();
+
+-- Tabela de Snapshots de Funções
+CREATE TABLE iam.role_snapshots (
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    role_id UUID NOT NULL,
+    version BIGINT NOT NULL,
+    state JSONB NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, role_id, version)
+);
+
+CREATE INDEX idx_role_snapshots_role_time ON iam.role_snapshots(tenant_id, role_id, taken_at);
+
+COMMENT ON TABLE iam.role_snapshots IS 'Estado materializado de funções em versões do histórico';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.role_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.role_snapshots ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.role_events
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.role_snapshots
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
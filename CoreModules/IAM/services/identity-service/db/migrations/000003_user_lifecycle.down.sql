@@ -0,0 +1,17 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte o ciclo de vida de contas de usuário
+ */
+
+DROP TABLE IF EXISTS iam.user_lifecycle_policies;
+DROP TABLE IF EXISTS iam.user_lifecycle_transitions;
+
+DROP INDEX IF EXISTS iam.idx_users_lifecycle;
+
+ALTER TABLE iam.users
+    DROP CONSTRAINT IF EXISTS ck_users_status,
+    DROP COLUMN IF EXISTS invitation_expires_at,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status_reason;
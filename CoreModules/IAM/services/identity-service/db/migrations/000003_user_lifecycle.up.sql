@@ -0,0 +1,67 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Ciclo de vida de contas de usuário
+ * Estados explícitos (invited, active, suspended, deprovisioned), registo das transições
+ * e políticas de suspensão por inatividade e desprovisionamento agendado por tenant.
+ */
+
+-- Dados de ciclo de vida na tabela de usuários
+ALTER TABLE iam.users
+    ADD COLUMN status_reason VARCHAR(255),
+    ADD COLUMN status_changed_at TIMESTAMPTZ,
+    ADD COLUMN invitation_expires_at TIMESTAMPTZ;
+
+ALTER TABLE iam.users
+    ADD CONSTRAINT ck_users_status CHECK (status IN (
+        'pending', 'invited', 'active', 'locked', 'suspended', 'disabled', 'deprovisioned'
+    ));
+
+-- Candidatos às transições automáticas
+CREATE INDEX idx_users_lifecycle ON iam.users(tenant_id, status, status_changed_at) WHERE deleted_at IS NULL;
+
+-- Tabela de Transições de Estado de Usuários
+CREATE TABLE iam.user_lifecycle_transitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    reason VARCHAR(255),
+    actor_id UUID,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sem chave estrangeira para iam.users: o registo sobrevive à remoção física do usuário
+CREATE INDEX idx_user_lifecycle_transitions_user ON iam.user_lifecycle_transitions(tenant_id, user_id, occurred_at);
+
+COMMENT ON TABLE iam.user_lifecycle_transitions IS 'Registo das mudanças de estado das contas de usuário';
+
+-- Tabela de Políticas de Ciclo de Vida por Tenant
+CREATE TABLE iam.user_lifecycle_policies (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    suspend_after_inactivity_days INTEGER NOT NULL DEFAULT 0,
+    deprovision_after_suspension_days INTEGER NOT NULL DEFAULT 0,
+    invitation_ttl_days INTEGER NOT NULL DEFAULT 7,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_by UUID,
+    CONSTRAINT ck_user_lifecycle_policies_days CHECK (
+        suspend_after_inactivity_days >= 0
+        AND deprovision_after_suspension_days >= 0
+        AND invitation_ttl_days >= 0
+    )
+);
+
+COMMENT ON TABLE iam.user_lifecycle_policies IS 'Prazos de suspensão por inatividade e desprovisionamento por tenant (0 desativa)';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.user_lifecycle_transitions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.user_lifecycle_policies ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.user_lifecycle_transitions
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.user_lifecycle_policies
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
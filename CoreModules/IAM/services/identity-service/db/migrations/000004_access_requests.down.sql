@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte os pedidos de acesso em autoatendimento
+ */
+
+DROP TABLE IF EXISTS iam.user_permissions;
+DROP TABLE IF EXISTS iam.access_request_approvers;
+DROP TABLE IF EXISTS iam.access_requests;
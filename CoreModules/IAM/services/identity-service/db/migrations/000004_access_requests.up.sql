@@ -0,0 +1,86 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Pedidos de acesso em autoatendimento
+ * Pedidos de função ou permissão com justificação, aprovadores por função ou delegados
+ * do tenant, e permissões concedidas diretamente aos usuários com validade opcional.
+ */
+
+-- Tabela de Pedidos de Acesso
+CREATE TABLE iam.access_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    requester_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL,
+    target_id UUID NOT NULL,
+    justification TEXT NOT NULL,
+    requested_expires_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    decided_by UUID,
+    decision_reason TEXT,
+    grant_expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMPTZ,
+    CONSTRAINT ck_access_requests_target_type CHECK (target_type IN ('role', 'permission')),
+    CONSTRAINT ck_access_requests_status CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    CONSTRAINT ck_access_requests_decision CHECK ((status = 'pending') = (decided_at IS NULL))
+);
+
+-- Um único pedido pendente por requerente e destino
+CREATE UNIQUE INDEX uk_access_requests_pending ON iam.access_requests(tenant_id, requester_id, target_type, target_id)
+    WHERE status = 'pending';
+CREATE INDEX idx_access_requests_status ON iam.access_requests(tenant_id, status, created_at);
+CREATE INDEX idx_access_requests_requester ON iam.access_requests(tenant_id, requester_id, created_at);
+CREATE INDEX idx_access_requests_decided ON iam.access_requests(tenant_id, decided_at) WHERE decided_at IS NOT NULL;
+
+COMMENT ON TABLE iam.access_requests IS 'Pedidos de função ou permissão feitos pelos próprios usuários';
+
+-- Tabela de Aprovadores de Pedidos de Acesso
+CREATE TABLE iam.access_request_approvers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    role_id UUID REFERENCES iam.roles(id) ON DELETE CASCADE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_access_request_approvers_kind CHECK (
+        (kind = 'role_owner' AND role_id IS NOT NULL)
+        OR (kind = 'delegated_admin' AND role_id IS NULL)
+    )
+);
+
+CREATE UNIQUE INDEX uk_access_request_approvers ON iam.access_request_approvers(
+    tenant_id, user_id, kind, COALESCE(role_id, '00000000-0000-0000-0000-000000000000'::UUID)
+);
+
+COMMENT ON TABLE iam.access_request_approvers IS 'Donos de função e administradores delegados que decidem pedidos de acesso';
+
+-- Tabela de Permissões Diretas de Usuários
+CREATE TABLE iam.user_permissions (
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES iam.permissions(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ,
+    granted_by UUID,
+    access_request_id UUID REFERENCES iam.access_requests(id),
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id, permission_id)
+);
+
+COMMENT ON TABLE iam.user_permissions IS 'Permissões atribuídas diretamente a usuários, sem função intermédia';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.access_requests ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.access_request_approvers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.user_permissions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.access_requests
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.access_request_approvers
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.user_permissions
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
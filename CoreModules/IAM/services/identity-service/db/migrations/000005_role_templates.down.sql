@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a biblioteca de modelos de função
+ */
+
+DROP TABLE IF EXISTS iam.role_template_instances;
+DROP TABLE IF EXISTS iam.role_templates;
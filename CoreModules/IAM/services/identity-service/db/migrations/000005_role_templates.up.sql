@@ -0,0 +1,57 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Biblioteca de modelos de função
+ * Catálogo global de modelos curados pelos operadores da plataforma e ligação
+ * das funções dos tenants ao modelo e à versão a partir dos quais foram instanciadas.
+ */
+
+-- Tabela de Modelos de Função (âmbito global, sem tenant)
+CREATE TABLE iam.role_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    market VARCHAR(50),
+    version INTEGER NOT NULL DEFAULT 1,
+    permission_codes TEXT[] NOT NULL,
+    metadata JSONB,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_role_templates_version CHECK (version >= 1),
+    CONSTRAINT ck_role_templates_permissions CHECK (cardinality(permission_codes) > 0)
+);
+
+-- Um único modelo por código e mercado (um mercado nulo abrange todos os mercados)
+CREATE UNIQUE INDEX uk_role_templates_code_market ON iam.role_templates(code, COALESCE(market, ''));
+CREATE INDEX idx_role_templates_market ON iam.role_templates(market) WHERE is_active;
+
+COMMENT ON TABLE iam.role_templates IS 'Modelos de função publicados pelos operadores da plataforma para instanciação nos tenants';
+
+-- Tabela de Instâncias de Modelos de Função
+CREATE TABLE iam.role_template_instances (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template_id UUID NOT NULL REFERENCES iam.role_templates(id),
+    template_version INTEGER NOT NULL,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    role_id UUID NOT NULL REFERENCES iam.roles(id) ON DELETE CASCADE,
+    permission_mapping JSONB,
+    unmapped_codes TEXT[],
+    instantiated_by UUID,
+    instantiated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Cada modelo é instanciado uma única vez por tenant e cada função provém de um único modelo
+CREATE UNIQUE INDEX uk_role_template_instances_template ON iam.role_template_instances(tenant_id, template_id);
+CREATE UNIQUE INDEX uk_role_template_instances_role ON iam.role_template_instances(tenant_id, role_id);
+
+COMMENT ON TABLE iam.role_template_instances IS 'Funções dos tenants instanciadas a partir de modelos, para deteção de desvios';
+
+-- Isolamento multi-tenant (o catálogo é global e não tem política de tenant)
+ALTER TABLE iam.role_template_instances ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.role_template_instances
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
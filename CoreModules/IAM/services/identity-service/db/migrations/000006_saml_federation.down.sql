@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a federação com fornecedores de identidade SAML 2.0
+ */
+
+DROP TABLE IF EXISTS iam.federated_identities;
+DROP TABLE IF EXISTS iam.saml_authn_requests;
+DROP TABLE IF EXISTS iam.saml_identity_providers;
@@ -0,0 +1,83 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Federação com fornecedores de identidade SAML 2.0
+ * Fornecedores de identidade confiáveis por tenant, com regras de mapeamento de
+ * atributos para funções, pedidos de autenticação pendentes (proteção contra
+ * repetição) e identidades federadas provisionadas no primeiro login.
+ */
+
+-- Tabela de Fornecedores de Identidade SAML
+CREATE TABLE iam.saml_identity_providers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    name VARCHAR(255) NOT NULL,
+    entity_id TEXT NOT NULL,
+    sso_url TEXT NOT NULL,
+    signing_certificates TEXT[] NOT NULL,
+    name_id_format TEXT,
+    attribute_mapping JSONB NOT NULL DEFAULT '{}',
+    role_mapping_rules JSONB NOT NULL DEFAULT '[]',
+    jit_provisioning BOOLEAN NOT NULL DEFAULT TRUE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uk_saml_identity_providers_entity UNIQUE(tenant_id, entity_id),
+    CONSTRAINT ck_saml_identity_providers_certificates CHECK (cardinality(signing_certificates) > 0)
+);
+
+CREATE INDEX idx_saml_identity_providers_tenant ON iam.saml_identity_providers(tenant_id);
+
+COMMENT ON TABLE iam.saml_identity_providers IS 'Fornecedores de identidade SAML 2.0 em que cada tenant confia';
+
+-- Tabela de Pedidos de Autenticação SAML pendentes
+-- Cada resposta do IdP tem de corresponder a um pedido emitido e ainda não consumido
+CREATE TABLE iam.saml_authn_requests (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    provider_id UUID NOT NULL REFERENCES iam.saml_identity_providers(id) ON DELETE CASCADE,
+    relay_state VARCHAR(255) NOT NULL,
+    return_to TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    CONSTRAINT uk_saml_authn_requests_relay_state UNIQUE(relay_state)
+);
+
+CREATE INDEX idx_saml_authn_requests_expires ON iam.saml_authn_requests(expires_at) WHERE consumed_at IS NULL;
+
+COMMENT ON TABLE iam.saml_authn_requests IS 'Pedidos de autenticação SAML emitidos, para validação de InResponseTo e proteção contra repetição';
+
+-- Tabela de Identidades Federadas
+CREATE TABLE iam.federated_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    provider_id UUID NOT NULL REFERENCES iam.saml_identity_providers(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    granted_role_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMPTZ,
+    CONSTRAINT uk_federated_identities_subject UNIQUE(provider_id, subject)
+);
+
+CREATE INDEX idx_federated_identities_user ON iam.federated_identities(tenant_id, user_id);
+
+COMMENT ON TABLE iam.federated_identities IS 'Ligação entre o NameID emitido por um IdP SAML e o usuário local';
+COMMENT ON COLUMN iam.federated_identities.granted_role_ids IS 'Funções atribuídas pelas regras de mapeamento, sincronizadas a cada login';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.saml_identity_providers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.saml_authn_requests ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.federated_identities ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.saml_identity_providers
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.saml_authn_requests
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.federated_identities
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
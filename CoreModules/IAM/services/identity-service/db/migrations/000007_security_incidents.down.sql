@@ -0,0 +1,8 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte os incidentes de segurança
+ */
+
+DROP TABLE IF EXISTS iam.security_incidents;
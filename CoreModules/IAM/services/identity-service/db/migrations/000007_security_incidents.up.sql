@@ -0,0 +1,40 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Incidentes de segurança
+ * Anomalias detetadas na análise do fluxo de auditoria, com as evidências recolhidas
+ * e o estado de triagem pela equipa de segurança do tenant.
+ */
+
+-- Tabela de Incidentes de Segurança
+CREATE TABLE iam.security_incidents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    rule VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    actor_id UUID,
+    summary TEXT NOT NULL,
+    evidence JSONB NOT NULL DEFAULT '[]'::JSONB,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    triaged_by UUID,
+    resolution_note TEXT,
+    closed_at TIMESTAMPTZ,
+    CONSTRAINT ck_security_incidents_severity CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT ck_security_incidents_status CHECK (status IN ('open', 'acknowledged', 'resolved', 'dismissed')),
+    CONSTRAINT ck_security_incidents_closed CHECK ((status IN ('resolved', 'dismissed')) = (closed_at IS NOT NULL))
+);
+
+CREATE INDEX idx_security_incidents_status ON iam.security_incidents(tenant_id, status, detected_at DESC);
+CREATE INDEX idx_security_incidents_severity ON iam.security_incidents(tenant_id, severity, detected_at DESC);
+CREATE INDEX idx_security_incidents_actor ON iam.security_incidents(tenant_id, actor_id) WHERE actor_id IS NOT NULL;
+
+COMMENT ON TABLE iam.security_incidents IS 'Anomalias detetadas no fluxo de auditoria e respetiva triagem';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.security_incidents ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.security_incidents
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,8 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as exportações de dados de tenants
+ */
+
+DROP TABLE IF EXISTS iam.tenant_exports;
@@ -0,0 +1,44 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Exportação de dados de tenants
+ * Arquivos gerados na saída de um tenant, com o progresso por secção que permite
+ * retomar a geração e o manifesto assinado do arquivo concluído.
+ */
+
+-- Tabela de Exportações de Tenants
+CREATE TABLE iam.tenant_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    market VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    pii_mode VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    progress JSONB NOT NULL DEFAULT '[]'::JSONB,
+    manifest JSONB,
+    error TEXT,
+    requested_by UUID NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT ck_tenant_exports_format CHECK (format IN ('json', 'csv')),
+    CONSTRAINT ck_tenant_exports_pii_mode CHECK (pii_mode IN ('include', 'mask', 'pseudonymize')),
+    CONSTRAINT ck_tenant_exports_status CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    CONSTRAINT ck_tenant_exports_completed CHECK ((status = 'completed') = (completed_at IS NOT NULL AND manifest IS NOT NULL))
+);
+
+-- Apenas uma exportação ativa por tenant
+CREATE UNIQUE INDEX uk_tenant_exports_active ON iam.tenant_exports(tenant_id) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_tenant_exports_tenant ON iam.tenant_exports(tenant_id, created_at DESC);
+CREATE INDEX idx_tenant_exports_active ON iam.tenant_exports(created_at) WHERE status IN ('pending', 'running');
+
+COMMENT ON TABLE iam.tenant_exports IS 'Exportações dos dados IAM de tenants na saída da plataforma';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.tenant_exports ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.tenant_exports
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
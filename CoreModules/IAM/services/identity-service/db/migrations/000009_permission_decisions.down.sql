@@ -0,0 +1,8 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a auditoria das decisões de autorização
+ */
+
+DROP TABLE IF EXISTS iam.permission_decisions;
@@ -0,0 +1,38 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Auditoria das decisões de autorização
+ * Decisões do PDP gravadas em lote: todas as negações e uma amostra das permissões,
+ * consultadas por usuário, recurso e período para responder a "quem acedeu a quê".
+ */
+
+-- Tabela de Decisões de Autorização (imutável)
+CREATE TABLE iam.permission_decisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    resource TEXT NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    policy_path VARCHAR(255),
+    request_id VARCHAR(100),
+    client_ip VARCHAR(50),
+    sample_rate DOUBLE PRECISION NOT NULL DEFAULT 1,
+    decided_at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_permission_decisions_sample_rate CHECK (sample_rate > 0 AND sample_rate <= 1)
+);
+
+CREATE INDEX idx_permission_decisions_user ON iam.permission_decisions(tenant_id, user_id, decided_at DESC);
+CREATE INDEX idx_permission_decisions_resource ON iam.permission_decisions(tenant_id, resource text_pattern_ops, decided_at DESC);
+CREATE INDEX idx_permission_decisions_decided_at ON iam.permission_decisions(tenant_id, decided_at DESC);
+CREATE INDEX idx_permission_decisions_denied ON iam.permission_decisions(tenant_id, decided_at DESC) WHERE NOT allowed;
+
+COMMENT ON TABLE iam.permission_decisions IS 'Decisões de autorização do PDP: todas as negações e uma amostra das permissões (imutável)';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.permission_decisions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.permission_decisions
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
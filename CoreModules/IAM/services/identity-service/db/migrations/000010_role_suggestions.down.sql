@@ -0,0 +1,8 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as sugestões de função
+ */
+
+DROP TABLE IF EXISTS iam.role_suggestions;
@@ -0,0 +1,42 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Sugestões de função mineradas das permissões diretas
+ * Conjuntos de permissões partilhados por vários usuários, propostos como funções
+ * candidatas e aceites (criando a função) ou descartados por um administrador.
+ */
+
+-- Tabela de Sugestões de Função
+CREATE TABLE iam.role_suggestions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    fingerprint VARCHAR(64) NOT NULL,
+    permission_ids UUID[] NOT NULL,
+    permission_codes TEXT[] NOT NULL,
+    user_ids UUID[] NOT NULL,
+    user_count INTEGER NOT NULL,
+    covered_assignments INTEGER NOT NULL,
+    cohesion DOUBLE PRECISION NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    role_id UUID REFERENCES iam.roles(id) ON DELETE SET NULL,
+    reviewed_by UUID,
+    reviewed_at TIMESTAMPTZ,
+    generated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_role_suggestions_status CHECK (status IN ('pending', 'accepted', 'dismissed')),
+    CONSTRAINT ck_role_suggestions_reviewed CHECK ((status = 'pending') = (reviewed_at IS NULL))
+);
+
+-- Um conjunto de permissões revisto não volta a ser sugerido
+CREATE UNIQUE INDEX uk_role_suggestions_reviewed ON iam.role_suggestions(tenant_id, fingerprint) WHERE status <> 'pending';
+CREATE INDEX idx_role_suggestions_tenant ON iam.role_suggestions(tenant_id, status, covered_assignments DESC);
+
+COMMENT ON TABLE iam.role_suggestions IS 'Funções candidatas mineradas das permissões atribuídas diretamente aos usuários';
+COMMENT ON COLUMN iam.role_suggestions.fingerprint IS 'SHA-256 dos IDs ordenados das permissões sugeridas';
+COMMENT ON COLUMN iam.role_suggestions.covered_assignments IS 'Atribuições diretas que a função substituiria (usuários × permissões)';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.role_suggestions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.role_suggestions
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a autenticação sem senha
+ */
+
+DROP TABLE IF EXISTS iam.passwordless_challenges;
+DROP TABLE IF EXISTS iam.passwordless_settings;
@@ -0,0 +1,62 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Autenticação sem senha
+ * Configuração por tenant dos links mágicos e dos códigos enviados por email, e os
+ * desafios emitidos (de uso único, com validade curta e tentativas limitadas).
+ */
+
+-- Tabela de Configuração da Autenticação sem Senha
+CREATE TABLE iam.passwordless_settings (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    methods TEXT[] NOT NULL,
+    device_binding VARCHAR(20) NOT NULL DEFAULT 'optional',
+    market VARCHAR(50),
+    financial_tenant BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_passwordless_settings_methods CHECK (cardinality(methods) > 0 AND methods <@ ARRAY['magic_link', 'email_otp']),
+    CONSTRAINT ck_passwordless_settings_device_binding CHECK (device_binding IN ('none', 'optional', 'required'))
+);
+
+COMMENT ON TABLE iam.passwordless_settings IS 'Métodos de autenticação sem senha aceites por cada tenant';
+COMMENT ON COLUMN iam.passwordless_settings.financial_tenant IS 'Tenant do setor financeiro, sujeito à política sem senha do mercado';
+
+-- Tabela de Desafios sem Senha
+-- Apenas o HMAC do segredo e do dispositivo é gravado
+CREATE TABLE iam.passwordless_challenges (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID REFERENCES iam.users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    method VARCHAR(20) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL,
+    device_hash VARCHAR(64),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    CONSTRAINT ck_passwordless_challenges_method CHECK (method IN ('magic_link', 'email_otp'))
+);
+
+CREATE INDEX idx_passwordless_challenges_email ON iam.passwordless_challenges(tenant_id, email, created_at);
+CREATE INDEX idx_passwordless_challenges_ip ON iam.passwordless_challenges(tenant_id, ip_address, created_at);
+CREATE INDEX idx_passwordless_challenges_expires ON iam.passwordless_challenges(expires_at) WHERE consumed_at IS NULL;
+
+COMMENT ON TABLE iam.passwordless_challenges IS 'Links mágicos e códigos de uso único emitidos, com o limite de pedidos e de tentativas';
+COMMENT ON COLUMN iam.passwordless_challenges.user_id IS 'Nulo nos pedidos para emails sem conta, que não são enviados';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.passwordless_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.passwordless_challenges ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.passwordless_settings
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.passwordless_challenges
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as políticas de rede por tenant
+ */
+
+DROP TABLE IF EXISTS iam.network_break_glass_uses;
+DROP TABLE IF EXISTS iam.network_break_glass;
+DROP TABLE IF EXISTS iam.network_policies;
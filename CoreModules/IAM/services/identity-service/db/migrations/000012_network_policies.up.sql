@@ -0,0 +1,85 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Políticas de rede por tenant
+ * Listas de endereços (CIDR) permitidos e bloqueados para a API de administração e
+ * para funções específicas, os acessos de emergência (break-glass) e a auditoria do seu uso.
+ */
+
+-- Tabela de Políticas de Rede
+CREATE TABLE iam.network_policies (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    name VARCHAR(100) NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    role_code VARCHAR(100),
+    cidrs CIDR[] NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_network_policies_name UNIQUE (tenant_id, name),
+    CONSTRAINT ck_network_policies_scope CHECK (scope IN ('admin_api', 'role')),
+    CONSTRAINT ck_network_policies_action CHECK (action IN ('allow', 'deny')),
+    CONSTRAINT ck_network_policies_role CHECK ((scope = 'role') = (role_code IS NOT NULL)),
+    CONSTRAINT ck_network_policies_cidrs CHECK (cardinality(cidrs) > 0)
+);
+
+COMMENT ON TABLE iam.network_policies IS 'Endereços permitidos e bloqueados por tenant, na API de administração e por função';
+COMMENT ON COLUMN iam.network_policies.role_code IS 'Função a que a política se aplica, nas políticas de âmbito role';
+
+-- Tabela de Acessos de Emergência
+CREATE TABLE iam.network_break_glass (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    justification TEXT NOT NULL,
+    ticket_ref VARCHAR(100),
+    granted_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID,
+    CONSTRAINT ck_network_break_glass_expiry CHECK (expires_at > created_at)
+);
+
+CREATE INDEX idx_network_break_glass_active ON iam.network_break_glass(tenant_id, expires_at) WHERE revoked_at IS NULL;
+
+COMMENT ON TABLE iam.network_break_glass IS 'Acessos de emergência temporários que ignoram as políticas de rede do tenant';
+
+-- Tabela de Usos dos Acessos de Emergência
+-- Cada pedido permitido apenas pelo acesso de emergência fica registado
+CREATE TABLE iam.network_break_glass_uses (
+    id UUID PRIMARY KEY,
+    override_id UUID NOT NULL REFERENCES iam.network_break_glass(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    method VARCHAR(10),
+    path TEXT,
+    blocked_reason VARCHAR(50) NOT NULL,
+    policy_id UUID,
+    used_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_network_break_glass_uses_override ON iam.network_break_glass_uses(override_id, used_at);
+
+COMMENT ON TABLE iam.network_break_glass_uses IS 'Auditoria dos pedidos permitidos por acesso de emergência';
+COMMENT ON COLUMN iam.network_break_glass_uses.policy_id IS 'Política que teria bloqueado o pedido';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.network_policies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.network_break_glass ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.network_break_glass_uses ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.network_policies
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.network_break_glass
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.network_break_glass_uses
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
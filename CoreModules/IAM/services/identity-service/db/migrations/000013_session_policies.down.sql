@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as políticas de sessão por tenant
+ */
+
+DROP TABLE IF EXISTS iam.session_activity;
+DROP TABLE IF EXISTS iam.session_policies;
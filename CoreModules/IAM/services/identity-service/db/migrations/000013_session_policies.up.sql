@@ -0,0 +1,53 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Políticas de sessão por tenant
+ * Validade dos tokens de acesso e de renovação, duração máxima das sessões e tempo de
+ * inatividade, e o registo de atividade das sessões usado para terminar as inativas.
+ */
+
+-- Tabela de Políticas de Sessão
+CREATE TABLE iam.session_policies (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    market VARCHAR(50),
+    financial_tenant BOOLEAN NOT NULL DEFAULT FALSE,
+    access_token_ttl_seconds INTEGER NOT NULL,
+    refresh_token_ttl_seconds INTEGER NOT NULL,
+    absolute_lifetime_seconds INTEGER NOT NULL,
+    idle_timeout_seconds INTEGER NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_session_policies_access CHECK (access_token_ttl_seconds >= 60 AND access_token_ttl_seconds <= refresh_token_ttl_seconds),
+    CONSTRAINT ck_session_policies_refresh CHECK (refresh_token_ttl_seconds <= absolute_lifetime_seconds),
+    CONSTRAINT ck_session_policies_idle CHECK (idle_timeout_seconds >= 60 AND idle_timeout_seconds <= absolute_lifetime_seconds)
+);
+
+COMMENT ON TABLE iam.session_policies IS 'Validade dos tokens e duração das sessões de cada tenant; sem registo aplicam-se os valores do mercado';
+COMMENT ON COLUMN iam.session_policies.financial_tenant IS 'Tenant do setor financeiro, sujeito aos limites de sessão do mercado';
+
+-- Tabela de Atividade das Sessões
+CREATE TABLE iam.session_activity (
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    session_id VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, session_id)
+);
+
+CREATE INDEX idx_session_activity_last_seen ON iam.session_activity(last_seen_at);
+
+COMMENT ON TABLE iam.session_activity IS 'Último pedido de cada sessão, usado para terminar as sessões inativas';
+COMMENT ON COLUMN iam.session_activity.ended_at IS 'Momento em que a sessão foi terminada por inatividade';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.session_policies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.session_activity ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.session_policies
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.session_activity
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
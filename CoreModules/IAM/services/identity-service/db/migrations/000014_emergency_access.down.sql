@@ -0,0 +1,11 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as contas de emergência (break-glass)
+ */
+
+DROP TABLE IF EXISTS iam.emergency_actions;
+DROP FUNCTION IF EXISTS iam.emergency_actions_append_only();
+DROP TABLE IF EXISTS iam.emergency_activations;
+DROP TABLE IF EXISTS iam.emergency_accounts;
@@ -0,0 +1,120 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Contas de emergência (break-glass)
+ * Contas pré-provisionadas seladas até uma ativação aprovada por uma segunda pessoa, com
+ * referência ao incidente e duração limitada, o registo encadeado por hashes das ações feitas
+ * durante cada ativação e a revisão das ativações terminadas.
+ */
+
+-- Tabela de Contas de Emergência
+CREATE TABLE iam.emergency_accounts (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_emergency_accounts_user UNIQUE (tenant_id, user_id),
+    CONSTRAINT uq_emergency_accounts_name UNIQUE (tenant_id, name)
+);
+
+COMMENT ON TABLE iam.emergency_accounts IS 'Contas de emergência do tenant; os pedidos do usuário são recusados fora das ativações em vigor';
+
+-- Tabela de Ativações das Contas de Emergência
+CREATE TABLE iam.emergency_activations (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    account_id UUID NOT NULL REFERENCES iam.emergency_accounts(id),
+    user_id UUID NOT NULL,
+    incident_ref VARCHAR(100) NOT NULL,
+    justification TEXT NOT NULL,
+    duration_seconds INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    requested_by UUID NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_by UUID,
+    decided_at TIMESTAMPTZ,
+    decision_note TEXT,
+    expires_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    ended_by UUID,
+    end_reason TEXT,
+    reviewed_by UUID,
+    reviewed_at TIMESTAMPTZ,
+    review_outcome VARCHAR(20),
+    review_notes TEXT,
+    CONSTRAINT ck_emergency_activations_status CHECK (status IN ('pending_approval', 'active', 'rejected', 'expired', 'closed', 'reviewed')),
+    CONSTRAINT ck_emergency_activations_duration CHECK (duration_seconds > 0),
+    CONSTRAINT ck_emergency_activations_requester CHECK (requested_by <> user_id),
+    CONSTRAINT ck_emergency_activations_dual_control CHECK (
+        status IN ('pending_approval', 'rejected') OR (decided_by IS NOT NULL AND decided_by <> requested_by AND expires_at IS NOT NULL)
+    ),
+    CONSTRAINT ck_emergency_activations_review CHECK (
+        (status = 'reviewed') = (reviewed_by IS NOT NULL)
+        AND (reviewed_by IS NULL OR (reviewed_by <> requested_by AND reviewed_by <> decided_by))
+    ),
+    CONSTRAINT ck_emergency_activations_outcome CHECK (review_outcome IS NULL OR review_outcome IN ('justified', 'unjustified'))
+);
+
+-- Uma conta tem no máximo uma ativação pendente ou em vigor
+CREATE UNIQUE INDEX uq_emergency_activations_open ON iam.emergency_activations(account_id)
+    WHERE status IN ('pending_approval', 'active');
+CREATE INDEX idx_emergency_activations_tenant ON iam.emergency_activations(tenant_id, status, requested_at DESC);
+CREATE INDEX idx_emergency_activations_due ON iam.emergency_activations(expires_at) WHERE status = 'active';
+
+COMMENT ON TABLE iam.emergency_activations IS 'Pedidos de ativação das contas de emergência, com aprovação, fim e revisão';
+COMMENT ON COLUMN iam.emergency_activations.incident_ref IS 'Referência obrigatória do incidente que justifica a ativação';
+COMMENT ON COLUMN iam.emergency_activations.decided_by IS 'Aprovador ou responsável pela recusa; vazio nas recusas automáticas';
+COMMENT ON COLUMN iam.emergency_activations.ended_by IS 'Responsável pelo fim antecipado; vazio quando a ativação expirou';
+
+-- Tabela de Ações das Contas de Emergência
+-- Cada registo inclui o hash do anterior da mesma ativação; a tabela só aceita inserções
+CREATE TABLE iam.emergency_actions (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    activation_id UUID NOT NULL REFERENCES iam.emergency_activations(id),
+    user_id UUID NOT NULL,
+    sequence BIGINT NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    query TEXT,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT,
+    request_id VARCHAR(100),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    CONSTRAINT uq_emergency_actions_sequence UNIQUE (activation_id, sequence),
+    CONSTRAINT ck_emergency_actions_sequence CHECK (sequence > 0)
+);
+
+COMMENT ON TABLE iam.emergency_actions IS 'Registo encadeado por hashes dos pedidos feitos pelas contas de emergência durante as ativações';
+COMMENT ON COLUMN iam.emergency_actions.prev_hash IS 'Hash do registo anterior da ativação; vazio no primeiro';
+
+CREATE OR REPLACE FUNCTION iam.emergency_actions_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'iam.emergency_actions só aceita inserções';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER emergency_actions_append_only_trigger
+BEFORE UPDATE OR DELETE ON iam.emergency_actions
+FOR EACH ROW EXECUTE FUNCTION iam.emergency_actions_append_only();
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.emergency_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.emergency_activations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.emergency_actions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.emergency_accounts
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.emergency_activations
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.emergency_actions
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as execuções agendadas dos testes de compliance
+ */
+
+DROP TABLE IF EXISTS iam.compliance_alerts;
+DROP TABLE IF EXISTS iam.compliance_runs;
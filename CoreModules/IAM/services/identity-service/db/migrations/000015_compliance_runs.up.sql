@@ -0,0 +1,60 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Execuções agendadas dos testes de compliance
+ * Sumários por região gravados pelo modo serve da CLI de compliance, usados para calcular a
+ * evolução das pontuações, e os alertas enviados quando uma pontuação desce abaixo do limite
+ * ou um requisito crítico passa a falhar.
+ */
+
+-- Tabela de Execuções de Compliance
+CREATE TABLE iam.compliance_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id UUID NOT NULL,
+    region VARCHAR(10) NOT NULL,
+    region_name VARCHAR(100),
+    target VARCHAR(10) NOT NULL,
+    policy_revision VARCHAR(255),
+    total_tests INTEGER NOT NULL,
+    passed_tests INTEGER NOT NULL,
+    failed_tests INTEGER NOT NULL,
+    compliance_score DOUBLE PRECISION NOT NULL,
+    framework_scores JSONB NOT NULL DEFAULT '{}',
+    requirements_failed TEXT[] NOT NULL DEFAULT '{}',
+    critical_requirements_failed TEXT[] NOT NULL DEFAULT '{}',
+    executed_at TIMESTAMPTZ NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    CONSTRAINT uq_compliance_runs_region UNIQUE (run_id, region),
+    CONSTRAINT ck_compliance_runs_tests CHECK (passed_tests + failed_tests <= total_tests)
+);
+
+CREATE INDEX idx_compliance_runs_region ON iam.compliance_runs(region, executed_at DESC);
+
+COMMENT ON TABLE iam.compliance_runs IS 'Sumários das execuções agendadas dos testes de compliance por região';
+COMMENT ON COLUMN iam.compliance_runs.run_id IS 'Execução agendada que avaliou todas as regiões configuradas';
+COMMENT ON COLUMN iam.compliance_runs.framework_scores IS 'Pontuação por framework, indexada pelo ID do framework';
+COMMENT ON COLUMN iam.compliance_runs.critical_requirements_failed IS 'Requisitos de criticidade alta com testes reprovados';
+
+-- Tabela de Alertas de Compliance
+CREATE TABLE iam.compliance_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id UUID NOT NULL,
+    region VARCHAR(10) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    framework_id VARCHAR(50),
+    requirement_id VARCHAR(50),
+    score DOUBLE PRECISION,
+    previous_score DOUBLE PRECISION,
+    threshold DOUBLE PRECISION,
+    message TEXT NOT NULL,
+    channels TEXT[] NOT NULL DEFAULT '{}',
+    delivery_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_compliance_alerts_kind CHECK (kind IN ('framework_below_threshold', 'critical_requirement_failed'))
+);
+
+CREATE INDEX idx_compliance_alerts_region ON iam.compliance_alerts(region, created_at DESC);
+
+COMMENT ON TABLE iam.compliance_alerts IS 'Alertas de desvio de compliance enviados por webhook ou email';
+COMMENT ON COLUMN iam.compliance_alerts.channels IS 'Canais para os quais o alerta foi entregue';
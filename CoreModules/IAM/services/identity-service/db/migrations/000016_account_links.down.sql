@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a ligação de contas entre fornecedores de identidade
+ */
+
+DROP TABLE IF EXISTS iam.account_link_events;
+DROP FUNCTION IF EXISTS iam.account_link_events_append_only();
+DROP TABLE IF EXISTS iam.account_link_candidates;
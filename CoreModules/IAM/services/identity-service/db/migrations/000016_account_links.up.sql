@@ -0,0 +1,80 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Ligação de contas entre fornecedores de identidade
+ * Candidatos à ligação detetados nos logins federados de NameIDs desconhecidos cujo email
+ * corresponde a uma conta local verificada, e o registo de auditoria de cada operação de
+ * ligação e remoção de identidades federadas.
+ */
+
+-- Tabela de Candidatos à Ligação de Contas
+CREATE TABLE iam.account_link_candidates (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    provider_id UUID NOT NULL REFERENCES iam.saml_identity_providers(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    CONSTRAINT ck_account_link_candidates_status CHECK (status IN ('pending', 'linked', 'dismissed', 'expired')),
+    CONSTRAINT ck_account_link_candidates_resolved CHECK ((status = 'pending') = (resolved_at IS NULL))
+);
+
+-- Um NameID do fornecedor tem no máximo um candidato pendente
+CREATE UNIQUE INDEX uq_account_link_candidates_pending ON iam.account_link_candidates(provider_id, subject)
+    WHERE status = 'pending';
+CREATE INDEX idx_account_link_candidates_user ON iam.account_link_candidates(tenant_id, user_id, detected_at DESC)
+    WHERE status = 'pending';
+
+COMMENT ON TABLE iam.account_link_candidates IS 'Identidades federadas desconhecidas cujo email verificado corresponde a uma conta local, à espera da ligação explícita pelo usuário';
+
+-- Tabela de Auditoria da Ligação de Contas
+-- Não referencia usuários nem fornecedores, para que o registo sobreviva à sua remoção; só aceita inserções
+CREATE TABLE iam.account_link_events (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL,
+    actor_id UUID,
+    action VARCHAR(30) NOT NULL,
+    provider_id UUID NOT NULL,
+    subject TEXT NOT NULL,
+    candidate_id UUID,
+    identity_id UUID,
+    authenticated_at TIMESTAMPTZ,
+    ip_address VARCHAR(45),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_account_link_events_action CHECK (action IN ('candidate_detected', 'candidate_dismissed', 'linked', 'unlinked')),
+    CONSTRAINT ck_account_link_events_reauth CHECK (action NOT IN ('linked', 'unlinked') OR authenticated_at IS NOT NULL)
+);
+
+CREATE INDEX idx_account_link_events_tenant ON iam.account_link_events(tenant_id, occurred_at DESC);
+CREATE INDEX idx_account_link_events_user ON iam.account_link_events(tenant_id, user_id, occurred_at DESC);
+
+COMMENT ON TABLE iam.account_link_events IS 'Registo de auditoria das deteções, ligações e remoções de identidades federadas';
+COMMENT ON COLUMN iam.account_link_events.actor_id IS 'Usuário que realizou a operação; vazio nas deteções feitas no login federado';
+COMMENT ON COLUMN iam.account_link_events.authenticated_at IS 'Instante da autenticação recente exigida para ligar e remover identidades';
+
+CREATE OR REPLACE FUNCTION iam.account_link_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'iam.account_link_events só aceita inserções';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER account_link_events_append_only_trigger
+BEFORE UPDATE OR DELETE ON iam.account_link_events
+FOR EACH ROW EXECUTE FUNCTION iam.account_link_events_append_only();
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.account_link_candidates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.account_link_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.account_link_candidates
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.account_link_events
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a aceitação de documentos legais
+ */
+
+DROP TABLE IF EXISTS iam.legal_acceptances;
+DROP FUNCTION IF EXISTS iam.legal_acceptances_append_only();
+DROP TABLE IF EXISTS iam.legal_documents;
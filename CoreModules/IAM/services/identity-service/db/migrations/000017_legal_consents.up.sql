@@ -0,0 +1,76 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Aceitação de documentos legais
+ * Versões publicadas dos termos de serviço, das políticas de privacidade e dos consentimentos
+ * de cada tenant, com o hash do conteúdo, e o registo imutável das aceitações dos usuários
+ * para prestação de contas perante a LGPD e o RGPD.
+ */
+
+-- Tabela de Documentos Legais
+CREATE TABLE iam.legal_documents (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    type VARCHAR(30) NOT NULL,
+    version VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    effective_at TIMESTAMPTZ NOT NULL,
+    published_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_legal_documents_version UNIQUE (tenant_id, type, version),
+    CONSTRAINT ck_legal_documents_type CHECK (type IN ('terms_of_service', 'privacy_policy', 'consent')),
+    CONSTRAINT ck_legal_documents_hash CHECK (content_hash ~ '^[0-9a-f]{64}$')
+);
+
+CREATE INDEX idx_legal_documents_effective ON iam.legal_documents(tenant_id, type, effective_at DESC, created_at DESC);
+
+COMMENT ON TABLE iam.legal_documents IS 'Versões publicadas dos documentos legais do tenant; a versão em vigor de cada tipo é a mais recente já em vigor';
+COMMENT ON COLUMN iam.legal_documents.content_hash IS 'SHA-256 hexadecimal do conteúdo publicado';
+COMMENT ON COLUMN iam.legal_documents.required IS 'A versão em vigor tem de ser aceite antes do login, quando o bloqueio está ativo';
+
+-- Tabela de Aceitações dos Documentos Legais
+-- Guarda o tipo, a versão e o hash aceites para que a prova não dependa do documento; só aceita inserções
+CREATE TABLE iam.legal_acceptances (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL,
+    document_id UUID NOT NULL REFERENCES iam.legal_documents(id),
+    document_type VARCHAR(30) NOT NULL,
+    version VARCHAR(50) NOT NULL,
+    document_hash CHAR(64) NOT NULL,
+    accepted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    CONSTRAINT uq_legal_acceptances_user_document UNIQUE (user_id, document_id)
+);
+
+CREATE INDEX idx_legal_acceptances_tenant ON iam.legal_acceptances(tenant_id, accepted_at DESC);
+CREATE INDEX idx_legal_acceptances_document ON iam.legal_acceptances(tenant_id, document_id, accepted_at DESC);
+
+COMMENT ON TABLE iam.legal_acceptances IS 'Registo imutável das aceitações de documentos legais pelos usuários';
+COMMENT ON COLUMN iam.legal_acceptances.document_hash IS 'Hash do conteúdo apresentado ao usuário no momento da aceitação';
+
+CREATE OR REPLACE FUNCTION iam.legal_acceptances_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'iam.legal_acceptances só aceita inserções';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER legal_acceptances_append_only_trigger
+BEFORE UPDATE OR DELETE ON iam.legal_acceptances
+FOR EACH ROW EXECUTE FUNCTION iam.legal_acceptances_append_only();
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.legal_documents ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.legal_acceptances ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.legal_documents
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.legal_acceptances
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
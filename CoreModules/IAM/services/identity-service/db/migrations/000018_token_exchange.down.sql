@@ -0,0 +1,10 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a troca de tokens para delegação
+ */
+
+DROP TABLE IF EXISTS iam.token_exchange_events;
+DROP FUNCTION IF EXISTS iam.token_exchange_events_append_only();
+DROP TABLE IF EXISTS iam.token_exchange_policies;
@@ -0,0 +1,84 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Troca de tokens para delegação (RFC 8693)
+ * Políticas por audiência com os serviços autorizados a obter tokens delegados, os escopos
+ * máximos, a validade e a profundidade de delegação, e o registo de auditoria das trocas.
+ */
+
+-- Tabela de Políticas de Troca de Tokens
+CREATE TABLE iam.token_exchange_policies (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    audience VARCHAR(255) NOT NULL,
+    description TEXT,
+    allowed_actors TEXT[] NOT NULL,
+    allowed_scopes TEXT[] NOT NULL,
+    max_ttl_seconds INTEGER NOT NULL,
+    max_delegation_depth INTEGER NOT NULL DEFAULT 1,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_token_exchange_policies_audience UNIQUE (tenant_id, audience),
+    CONSTRAINT ck_token_exchange_policies_actors CHECK (cardinality(allowed_actors) > 0),
+    CONSTRAINT ck_token_exchange_policies_scopes CHECK (cardinality(allowed_scopes) > 0),
+    CONSTRAINT ck_token_exchange_policies_ttl CHECK (max_ttl_seconds BETWEEN 1 AND 3600),
+    CONSTRAINT ck_token_exchange_policies_depth CHECK (max_delegation_depth BETWEEN 1 AND 5)
+);
+
+COMMENT ON TABLE iam.token_exchange_policies IS 'Serviços autorizados a obter tokens delegados para cada audiência do tenant e os limites desses tokens';
+COMMENT ON COLUMN iam.token_exchange_policies.allowed_actors IS 'Identificadores (client_id ou subject) dos serviços que podem agir em nome dos titulares';
+COMMENT ON COLUMN iam.token_exchange_policies.max_delegation_depth IS 'Número máximo de atores na claim act dos tokens emitidos';
+
+-- Tabela de Auditoria das Trocas de Tokens
+-- Não referencia as políticas, para que o registo sobreviva à sua remoção; só aceita inserções
+CREATE TABLE iam.token_exchange_events (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    subject VARCHAR(255) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    actor_chain TEXT[] NOT NULL DEFAULT '{}',
+    audience VARCHAR(255) NOT NULL,
+    policy_id UUID,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    outcome VARCHAR(10) NOT NULL,
+    reason VARCHAR(50),
+    token_id VARCHAR(64),
+    expires_at TIMESTAMPTZ,
+    ip_address VARCHAR(45),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_token_exchange_events_outcome CHECK (outcome IN ('issued', 'denied')),
+    CONSTRAINT ck_token_exchange_events_issued CHECK ((outcome = 'issued') = (token_id IS NOT NULL))
+);
+
+CREATE INDEX idx_token_exchange_events_tenant ON iam.token_exchange_events(tenant_id, occurred_at DESC);
+CREATE INDEX idx_token_exchange_events_subject ON iam.token_exchange_events(tenant_id, subject, occurred_at DESC);
+CREATE INDEX idx_token_exchange_events_actor ON iam.token_exchange_events(tenant_id, actor, occurred_at DESC);
+
+COMMENT ON TABLE iam.token_exchange_events IS 'Registo de auditoria das trocas de tokens concedidas e recusadas';
+COMMENT ON COLUMN iam.token_exchange_events.actor_chain IS 'Atores do token emitido ou pedido, do atual ao primeiro';
+COMMENT ON COLUMN iam.token_exchange_events.reason IS 'Erro do RFC 8693 nas trocas recusadas';
+
+CREATE OR REPLACE FUNCTION iam.token_exchange_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'iam.token_exchange_events só aceita inserções';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER token_exchange_events_append_only_trigger
+BEFORE UPDATE OR DELETE ON iam.token_exchange_events
+FOR EACH ROW EXECUTE FUNCTION iam.token_exchange_events_append_only();
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.token_exchange_policies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.token_exchange_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.token_exchange_policies
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.token_exchange_events
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,18 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a consulta dos registos de auditoria
+ */
+
+DROP INDEX IF EXISTS iam.idx_audit_logs_tenant_market;
+DROP INDEX IF EXISTS iam.idx_audit_logs_tenant_severity;
+DROP INDEX IF EXISTS iam.idx_audit_logs_tenant_created;
+DROP INDEX IF EXISTS iam.idx_audit_logs_search;
+
+ALTER TABLE iam.audit_logs
+    DROP CONSTRAINT IF EXISTS ck_audit_logs_severity,
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS details,
+    DROP COLUMN IF EXISTS market,
+    DROP COLUMN IF EXISTS severity;
@@ -0,0 +1,29 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Consulta dos registos de auditoria pelas equipas de compliance
+ * Acrescenta a severidade, o mercado e os detalhes legíveis dos registos, o índice de pesquisa
+ * em texto livre e o índice da paginação por cursor.
+ */
+
+ALTER TABLE iam.audit_logs
+    ADD COLUMN severity VARCHAR(20) NOT NULL DEFAULT 'info',
+    ADD COLUMN market VARCHAR(50),
+    ADD COLUMN details TEXT,
+    ADD CONSTRAINT ck_audit_logs_severity CHECK (severity IN ('info', 'low', 'medium', 'high', 'critical'));
+
+-- Configuração simple: os detalhes são escritos em vários idiomas e incluem identificadores
+ALTER TABLE iam.audit_logs
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', coalesce(details, '') || ' ' || event_type || ' ' || entity_type)
+    ) STORED;
+
+CREATE INDEX idx_audit_logs_search ON iam.audit_logs USING GIN (search_vector);
+CREATE INDEX idx_audit_logs_tenant_created ON iam.audit_logs(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_audit_logs_tenant_severity ON iam.audit_logs(tenant_id, severity, created_at DESC);
+CREATE INDEX idx_audit_logs_tenant_market ON iam.audit_logs(tenant_id, market, created_at DESC) WHERE market IS NOT NULL;
+
+COMMENT ON COLUMN iam.audit_logs.severity IS 'Severidade do registo: info, low, medium, high ou critical';
+COMMENT ON COLUMN iam.audit_logs.market IS 'Mercado regulatório em que a ação ocorreu';
+COMMENT ON COLUMN iam.audit_logs.details IS 'Descrição legível da ação, pesquisada em texto livre';
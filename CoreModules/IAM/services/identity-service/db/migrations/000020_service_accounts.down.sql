@@ -0,0 +1,11 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as contas de serviço
+ */
+
+DROP TABLE IF EXISTS iam.service_account_usage;
+DROP TABLE IF EXISTS iam.service_account_credentials;
+DROP TABLE IF EXISTS iam.service_account_roles;
+DROP TABLE IF EXISTS iam.service_accounts;
@@ -0,0 +1,128 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Contas de serviço
+ * Identidades dos workloads, distintas dos usuários, com funções atribuídas, credenciais
+ * (chave de API, certificado mTLS, cliente OIDC), ligação opcional a uma conta de serviço
+ * Kubernetes e a utilização diária para identificar as contas inativas.
+ */
+
+-- Tabela de Contas de Serviço
+-- A ligação Kubernetes (cluster, namespace, conta) é opcional, mas completa quando existe
+CREATE TABLE iam.service_accounts (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    name VARCHAR(63) NOT NULL,
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    k8s_cluster VARCHAR(63),
+    k8s_namespace VARCHAR(63),
+    k8s_service_account VARCHAR(253),
+    last_used_at TIMESTAMPTZ,
+    created_by UUID,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_service_accounts_name UNIQUE (tenant_id, name),
+    CONSTRAINT ck_service_accounts_status CHECK (status IN ('active', 'disabled')),
+    CONSTRAINT ck_service_accounts_workload CHECK (
+        (k8s_cluster IS NULL) = (k8s_namespace IS NULL) AND (k8s_cluster IS NULL) = (k8s_service_account IS NULL)
+    )
+);
+
+-- Uma conta de serviço Kubernetes identifica uma única conta de serviço da plataforma
+CREATE UNIQUE INDEX uq_service_accounts_workload ON iam.service_accounts(k8s_cluster, k8s_namespace, k8s_service_account)
+    WHERE k8s_cluster IS NOT NULL;
+
+COMMENT ON TABLE iam.service_accounts IS 'Identidades dos workloads do tenant, distintas dos usuários';
+COMMENT ON COLUMN iam.service_accounts.name IS 'Nome da conta, usado como client_id nos tokens emitidos e nas políticas de troca de tokens';
+COMMENT ON COLUMN iam.service_accounts.k8s_service_account IS 'Conta de serviço Kubernetes cujos tokens, validados por TokenReview, autenticam a conta';
+COMMENT ON COLUMN iam.service_accounts.last_used_at IS 'Última autenticação bem-sucedida, por qualquer método';
+
+-- Tabela de Funções das Contas de Serviço
+CREATE TABLE iam.service_account_roles (
+    service_account_id UUID NOT NULL REFERENCES iam.service_accounts(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES iam.roles(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    granted_by UUID,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service_account_id, role_id)
+);
+
+CREATE INDEX idx_service_account_roles_role ON iam.service_account_roles(role_id);
+
+COMMENT ON TABLE iam.service_account_roles IS 'Funções atribuídas às contas de serviço';
+
+-- Tabela de Credenciais das Contas de Serviço
+-- Os segredos das chaves de API e dos clientes OIDC só são guardados como hash SHA-256
+CREATE TABLE iam.service_account_credentials (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    service_account_id UUID NOT NULL REFERENCES iam.service_accounts(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    name VARCHAR(100),
+    key_prefix VARCHAR(32),
+    client_id VARCHAR(64),
+    secret_hash BYTEA,
+    certificate_fingerprint CHAR(64),
+    certificate_subject TEXT,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_by UUID,
+    revoked_at TIMESTAMPTZ,
+    CONSTRAINT uq_service_account_credentials_key_prefix UNIQUE (key_prefix),
+    CONSTRAINT uq_service_account_credentials_client_id UNIQUE (client_id),
+    CONSTRAINT uq_service_account_credentials_fingerprint UNIQUE (certificate_fingerprint),
+    CONSTRAINT ck_service_account_credentials_type CHECK (type IN ('api_key', 'mtls_cert', 'oidc_client')),
+    CONSTRAINT ck_service_account_credentials_api_key CHECK (
+        type <> 'api_key' OR (key_prefix IS NOT NULL AND secret_hash IS NOT NULL)
+    ),
+    CONSTRAINT ck_service_account_credentials_oidc_client CHECK (
+        type <> 'oidc_client' OR (client_id IS NOT NULL AND secret_hash IS NOT NULL)
+    ),
+    CONSTRAINT ck_service_account_credentials_mtls_cert CHECK (
+        type <> 'mtls_cert' OR certificate_fingerprint IS NOT NULL
+    )
+);
+
+CREATE INDEX idx_service_account_credentials_account ON iam.service_account_credentials(service_account_id, created_at DESC);
+
+COMMENT ON TABLE iam.service_account_credentials IS 'Chaves de API, certificados mTLS e clientes OIDC das contas de serviço';
+COMMENT ON COLUMN iam.service_account_credentials.key_prefix IS 'Parte pública da chave de API, usada para a encontrar';
+COMMENT ON COLUMN iam.service_account_credentials.certificate_fingerprint IS 'SHA-256 do certificado em DER, em hexadecimal';
+
+-- Tabela de Utilização Diária das Contas de Serviço
+CREATE TABLE iam.service_account_usage (
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    service_account_id UUID NOT NULL REFERENCES iam.service_accounts(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    method VARCHAR(20) NOT NULL,
+    authentications INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (service_account_id, day, method),
+    CONSTRAINT ck_service_account_usage_method CHECK (method IN ('api_key', 'mtls_cert', 'oidc_client', 'kubernetes'))
+);
+
+CREATE INDEX idx_service_account_usage_tenant ON iam.service_account_usage(tenant_id, day DESC);
+
+COMMENT ON TABLE iam.service_account_usage IS 'Autenticações diárias das contas de serviço por método';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.service_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.service_account_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.service_account_credentials ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.service_account_usage ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.service_accounts
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.service_account_roles
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.service_account_credentials
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.service_account_usage
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
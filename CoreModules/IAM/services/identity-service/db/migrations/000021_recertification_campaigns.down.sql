@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as campanhas de recertificação de acessos
+ */
+
+DROP TABLE IF EXISTS iam.recertification_items;
+DROP TABLE IF EXISTS iam.recertification_campaigns;
@@ -0,0 +1,96 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Campanhas de recertificação de acessos
+ * Campanhas de revisão das atribuições de funções de um tenant, com um item por atribuição no
+ * âmbito, decisões dos revisores até ao prazo, escalamento dos itens por decidir e revogação
+ * automática dos itens não decididos no fim do prazo.
+ */
+
+-- Tabela de Campanhas de Recertificação
+CREATE TABLE iam.recertification_campaigns (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    name VARCHAR(150) NOT NULL,
+    description TEXT,
+    role_ids UUID[] NOT NULL,
+    user_ids UUID[] NOT NULL DEFAULT '{}',
+    default_reviewer_id UUID NOT NULL,
+    escalation_reviewer_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    item_count INTEGER NOT NULL,
+    escalate_at TIMESTAMPTZ NOT NULL,
+    deadline TIMESTAMPTZ NOT NULL,
+    escalated_at TIMESTAMPTZ,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    cancelled_by UUID,
+    cancelled_at TIMESTAMPTZ,
+    cancel_reason TEXT,
+    CONSTRAINT ck_recertification_campaigns_status CHECK (status IN ('active', 'completed', 'cancelled')),
+    CONSTRAINT ck_recertification_campaigns_roles CHECK (cardinality(role_ids) > 0),
+    CONSTRAINT ck_recertification_campaigns_reviewers CHECK (default_reviewer_id <> escalation_reviewer_id),
+    CONSTRAINT ck_recertification_campaigns_schedule CHECK (escalate_at < deadline),
+    CONSTRAINT ck_recertification_campaigns_completed CHECK ((status = 'completed') = (completed_at IS NOT NULL)),
+    CONSTRAINT ck_recertification_campaigns_cancelled CHECK ((status = 'cancelled') = (cancelled_at IS NOT NULL))
+);
+
+CREATE INDEX idx_recertification_campaigns_tenant ON iam.recertification_campaigns(tenant_id, status, created_at DESC);
+CREATE INDEX idx_recertification_campaigns_due ON iam.recertification_campaigns(deadline, escalate_at) WHERE status = 'active';
+
+COMMENT ON TABLE iam.recertification_campaigns IS 'Campanhas de revisão das atribuições de funções do tenant';
+COMMENT ON COLUMN iam.recertification_campaigns.user_ids IS 'População de usuários revista; vazia abrange todos os titulares das funções';
+COMMENT ON COLUMN iam.recertification_campaigns.escalation_reviewer_id IS 'Revisor que recebe os itens por decidir na data de escalamento';
+
+-- Tabela de Itens de Recertificação
+-- Um item por atribuição de função no âmbito da campanha
+CREATE TABLE iam.recertification_items (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    campaign_id UUID NOT NULL REFERENCES iam.recertification_campaigns(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    role_id UUID NOT NULL,
+    role_code VARCHAR(100) NOT NULL,
+    role_name VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMPTZ NOT NULL,
+    assigned_by UUID NOT NULL,
+    assignment_expires_at TIMESTAMPTZ,
+    reviewer_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    escalated BOOLEAN NOT NULL DEFAULT FALSE,
+    escalated_at TIMESTAMPTZ,
+    decided_by UUID,
+    decided_at TIMESTAMPTZ,
+    comment TEXT,
+    auto_revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    revoked_at TIMESTAMPTZ,
+    revocation_error TEXT,
+    CONSTRAINT uq_recertification_items_assignment UNIQUE (campaign_id, role_id, user_id),
+    CONSTRAINT ck_recertification_items_status CHECK (status IN ('pending', 'approved', 'revoked')),
+    CONSTRAINT ck_recertification_items_self_review CHECK (decided_by IS NULL OR decided_by <> user_id),
+    CONSTRAINT ck_recertification_items_decision CHECK ((status = 'pending') = (decided_at IS NULL)),
+    CONSTRAINT ck_recertification_items_auto_revoked CHECK (NOT auto_revoked OR (status = 'revoked' AND decided_by IS NULL)),
+    CONSTRAINT ck_recertification_items_revocation CHECK (revoked_at IS NULL OR status = 'revoked')
+);
+
+CREATE INDEX idx_recertification_items_campaign ON iam.recertification_items(campaign_id, status);
+CREATE INDEX idx_recertification_items_reviewer ON iam.recertification_items(tenant_id, reviewer_id) WHERE status = 'pending';
+CREATE INDEX idx_recertification_items_revocations ON iam.recertification_items(decided_at)
+    WHERE status = 'revoked' AND revoked_at IS NULL;
+
+COMMENT ON TABLE iam.recertification_items IS 'Itens de revisão das campanhas de recertificação, com a decisão e a revogação da atribuição';
+COMMENT ON COLUMN iam.recertification_items.decided_by IS 'Revisor que decidiu o item; vazio nas revogações automáticas';
+COMMENT ON COLUMN iam.recertification_items.revoked_at IS 'Momento em que a atribuição foi retirada; vazio enquanto a revogação não foi aplicada';
+COMMENT ON COLUMN iam.recertification_items.revocation_error IS 'Última falha ao retirar a atribuição, repetida pelas execuções agendadas';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.recertification_campaigns ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.recertification_items ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.recertification_campaigns
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.recertification_items
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte os atributos personalizados dos usuários
+ */
+
+DROP TABLE IF EXISTS iam.user_attributes;
+DROP TABLE IF EXISTS iam.user_attribute_schemas;
@@ -0,0 +1,67 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Atributos personalizados dos usuários
+ * Esquemas tipados dos atributos de cada tenant (número de colaborador, centro de custo,
+ * nível de credenciação, ...) e os valores dos atributos de cada usuário, usados nas
+ * políticas ABAC e emitidos como claims personalizadas nos tokens.
+ */
+
+-- Tabela de Esquemas dos Atributos
+-- O nome e o tipo não mudam depois de criado o esquema; a claim é opcional e única no tenant
+CREATE TABLE iam.user_attribute_schemas (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    name VARCHAR(63) NOT NULL,
+    display_name VARCHAR(255),
+    description TEXT,
+    type VARCHAR(20) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    enum_values TEXT[] NOT NULL DEFAULT '{}',
+    pattern TEXT,
+    max_length INTEGER NOT NULL DEFAULT 0,
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+    claim_name VARCHAR(128),
+    created_by UUID,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_user_attribute_schemas_name UNIQUE (tenant_id, name),
+    CONSTRAINT ck_user_attribute_schemas_type CHECK (type IN ('string', 'integer', 'number', 'boolean', 'date', 'enum')),
+    CONSTRAINT ck_user_attribute_schemas_bounds CHECK (min_value IS NULL OR max_value IS NULL OR min_value <= max_value)
+);
+
+CREATE UNIQUE INDEX uq_user_attribute_schemas_claim ON iam.user_attribute_schemas(tenant_id, claim_name)
+    WHERE claim_name IS NOT NULL;
+
+COMMENT ON TABLE iam.user_attribute_schemas IS 'Esquemas tipados dos atributos personalizados dos usuários do tenant';
+COMMENT ON COLUMN iam.user_attribute_schemas.required IS 'Atributo exigido nas escritas dos atributos de cada usuário';
+COMMENT ON COLUMN iam.user_attribute_schemas.pattern IS 'Expressão regular (RE2) dos atributos do tipo string';
+COMMENT ON COLUMN iam.user_attribute_schemas.claim_name IS 'Claim com que o atributo é emitido nos tokens; vazia quando o atributo não entra nos tokens';
+
+-- Tabela dos Atributos dos Usuários
+-- Os valores são validados pelo serviço contra os esquemas do tenant, indexados pelo nome do atributo
+CREATE TABLE iam.user_attributes (
+    user_id UUID PRIMARY KEY REFERENCES iam.users(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    attribute_values JSONB NOT NULL DEFAULT '{}',
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_attributes_tenant ON iam.user_attributes(tenant_id);
+CREATE INDEX idx_user_attributes_values ON iam.user_attributes USING GIN (attribute_values);
+
+COMMENT ON TABLE iam.user_attributes IS 'Valores dos atributos personalizados de cada usuário';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.user_attribute_schemas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.user_attributes ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.user_attribute_schemas
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.user_attributes
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte o histórico das falhas de compliance
+ */
+
+ALTER TABLE iam.compliance_runs
+    DROP COLUMN IF EXISTS failed_tests;
@@ -0,0 +1,14 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Histórico das falhas de compliance
+ * Acrescenta aos sumários das execuções os testes reprovados, gravados pelo modo serve e pelas
+ * execuções com --history-db, usados pelo scorecard para as violações recorrentes e o tempo
+ * médio de remediação.
+ */
+
+ALTER TABLE iam.compliance_runs
+    ADD COLUMN failed_tests JSONB NOT NULL DEFAULT '[]';
+
+COMMENT ON COLUMN iam.compliance_runs.failed_tests IS 'Testes reprovados com os requisitos, frameworks, criticidade e violações';
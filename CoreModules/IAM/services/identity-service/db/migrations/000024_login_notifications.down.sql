@@ -0,0 +1,11 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as notificações de logins suspeitos
+ */
+
+DROP TABLE IF EXISTS iam.login_notifications;
+DROP TABLE IF EXISTS iam.login_known_contexts;
+DROP TABLE IF EXISTS iam.login_notification_templates;
+DROP TABLE IF EXISTS iam.login_notification_settings;
@@ -0,0 +1,105 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Notificações de logins suspeitos
+ * Configuração e modelos de mensagem de cada tenant, dispositivos e localizações conhecidos
+ * de cada usuário e as notificações enviadas, com o link "não fui eu" que termina as sessões
+ * do usuário e inicia a redefinição da credencial.
+ */
+
+-- Tabela de Configuração das Notificações de Login
+CREATE TABLE iam.login_notification_settings (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    channels TEXT[] NOT NULL DEFAULT '{email}',
+    market VARCHAR(50),
+    default_locale VARCHAR(10),
+    notify_first_login BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE iam.login_notification_settings IS 'Canais, mercado e idioma das notificações de logins suspeitos de cada tenant; sem registo as notificações estão desativadas';
+COMMENT ON COLUMN iam.login_notification_settings.notify_first_login IS 'Avisa também no primeiro login registado do usuário';
+
+-- Tabela dos Modelos de Mensagem
+-- O mercado vazio aplica-se a todos os mercados do tenant
+CREATE TABLE iam.login_notification_templates (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    kind VARCHAR(30) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    market VARCHAR(50) NOT NULL DEFAULT '',
+    locale VARCHAR(10) NOT NULL,
+    subject VARCHAR(200),
+    body TEXT NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_login_notification_templates UNIQUE (tenant_id, kind, channel, market, locale),
+    CONSTRAINT ck_login_notification_templates_kind CHECK (kind IN ('login_alert', 'credential_reset')),
+    CONSTRAINT ck_login_notification_templates_channel CHECK (channel IN ('email', 'sms', 'push'))
+);
+
+COMMENT ON TABLE iam.login_notification_templates IS 'Mensagens personalizadas pelo tenant por canal, mercado e idioma (text/template)';
+
+-- Tabela dos Contextos de Login Conhecidos
+-- Apenas o HMAC do dispositivo e da localização é gravado
+CREATE TABLE iam.login_known_contexts (
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, kind, fingerprint),
+    CONSTRAINT ck_login_known_contexts_kind CHECK (kind IN ('device', 'location'))
+);
+
+COMMENT ON TABLE iam.login_known_contexts IS 'Dispositivos e localizações usados por cada usuário; os não usados durante a retenção voltam a ser desconhecidos';
+
+-- Tabela das Notificações de Login
+CREATE TABLE iam.login_notifications (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    reasons TEXT[] NOT NULL,
+    method VARCHAR(50),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    country VARCHAR(2),
+    contexts JSONB NOT NULL DEFAULT '[]',
+    deliveries JSONB NOT NULL DEFAULT '[]',
+    response_hash VARCHAR(64) NOT NULL,
+    login_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    responded_at TIMESTAMPTZ,
+    sessions_ended INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_login_notifications_user ON iam.login_notifications(tenant_id, user_id, created_at DESC);
+
+COMMENT ON TABLE iam.login_notifications IS 'Notificações de logins suspeitos enviadas aos usuários';
+COMMENT ON COLUMN iam.login_notifications.contexts IS 'Contextos do login notificado, esquecidos quando o usuário responde "não fui eu"';
+COMMENT ON COLUMN iam.login_notifications.deliveries IS 'Resultado da entrega em cada canal';
+COMMENT ON COLUMN iam.login_notifications.response_hash IS 'HMAC do segredo do link "não fui eu", de uso único';
+COMMENT ON COLUMN iam.login_notifications.responded_at IS 'Momento da resposta "não fui eu"';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.login_notification_settings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.login_notification_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.login_known_contexts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.login_notifications ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.login_notification_settings
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.login_notification_templates
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.login_known_contexts
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.login_notifications
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,8 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte os limites de permissões
+ */
+
+DROP TABLE IF EXISTS iam.permission_boundaries;
@@ -0,0 +1,37 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Limites de permissões (permission boundaries)
+ * Conjunto máximo de permissões de cada função, incluindo as herdadas, e de cada
+ * administrador delegado, verificado nas atribuições e na avaliação do PDP.
+ * As tentativas de ultrapassar um limite são registadas em iam.security_incidents.
+ */
+
+-- Tabela dos Limites de Permissões
+-- Cada titular tem no máximo um limite; o titular não muda depois de criado o limite
+CREATE TABLE iam.permission_boundaries (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_by UUID,
+    updated_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_permission_boundaries_subject UNIQUE (tenant_id, subject_type, subject_id),
+    CONSTRAINT ck_permission_boundaries_subject_type CHECK (subject_type IN ('role', 'delegated_admin'))
+);
+
+COMMENT ON TABLE iam.permission_boundaries IS 'Conjunto máximo de permissões das funções e dos administradores delegados do tenant';
+COMMENT ON COLUMN iam.permission_boundaries.subject_id IS 'Função (subject_type role) ou usuário (subject_type delegated_admin) titular do limite';
+COMMENT ON COLUMN iam.permission_boundaries.permissions IS 'Códigos de permissões, "*" ou prefixos terminados em ":*"; vazio não permite nenhuma permissão';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.permission_boundaries ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.permission_boundaries
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
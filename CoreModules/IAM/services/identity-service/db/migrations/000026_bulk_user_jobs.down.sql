@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte as operações em massa sobre usuários
+ */
+
+DROP TABLE IF EXISTS iam.bulk_user_job_items;
+DROP TABLE IF EXISTS iam.bulk_user_jobs;
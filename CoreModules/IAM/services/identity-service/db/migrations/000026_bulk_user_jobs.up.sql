@@ -0,0 +1,78 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Operações em massa sobre usuários
+ * Tarefas assíncronas que desativam, reativam ou atribuem e revogam funções a milhares
+ * de usuários, com o resultado de cada item para o relatório de falhas parciais e a retoma.
+ */
+
+-- Tabela de Tarefas de Operação em Massa
+CREATE TABLE iam.bulk_user_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    operation VARCHAR(20) NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'pending',
+    total INTEGER NOT NULL,
+    processed INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    requested_by UUID NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    CONSTRAINT ck_bulk_user_jobs_operation CHECK (operation IN ('disable', 'enable', 'assign_role', 'revoke_role')),
+    CONSTRAINT ck_bulk_user_jobs_status CHECK (status IN ('pending', 'running', 'completed', 'completed_with_errors', 'failed')),
+    CONSTRAINT ck_bulk_user_jobs_counters CHECK (
+        total > 0 AND processed = succeeded + skipped + failed AND processed <= total
+    ),
+    CONSTRAINT ck_bulk_user_jobs_completed CHECK (
+        (status IN ('completed', 'completed_with_errors')) = (completed_at IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_bulk_user_jobs_tenant ON iam.bulk_user_jobs(tenant_id, created_at DESC);
+CREATE INDEX idx_bulk_user_jobs_active ON iam.bulk_user_jobs(created_at) WHERE status IN ('pending', 'running');
+
+COMMENT ON TABLE iam.bulk_user_jobs IS 'Tarefas assíncronas de operação em massa sobre os usuários do tenant';
+COMMENT ON COLUMN iam.bulk_user_jobs.processed IS 'Itens com resultado; os itens falhados voltam a pendentes quando a tarefa é retomada';
+
+-- Tabela de Itens das Tarefas
+-- Cada item é identificado pela linha do conteúdo enviado; um usuário consta uma única vez da tarefa
+CREATE TABLE iam.bulk_user_job_items (
+    job_id UUID NOT NULL REFERENCES iam.bulk_user_jobs(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    line INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    role_id UUID,
+    reason TEXT,
+    expires_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    code VARCHAR(50),
+    message TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (job_id, line),
+    CONSTRAINT uq_bulk_user_job_items_user UNIQUE (job_id, user_id),
+    CONSTRAINT ck_bulk_user_job_items_status CHECK (status IN ('pending', 'succeeded', 'skipped', 'failed')),
+    CONSTRAINT ck_bulk_user_job_items_processed CHECK ((status = 'pending') = (processed_at IS NULL))
+);
+
+CREATE INDEX idx_bulk_user_job_items_status ON iam.bulk_user_job_items(job_id, status, line);
+
+COMMENT ON TABLE iam.bulk_user_job_items IS 'Usuários de cada tarefa de operação em massa e o resultado da operação sobre cada um';
+COMMENT ON COLUMN iam.bulk_user_job_items.code IS 'Código do resultado dos itens ignorados ou falhados (ex.: already_applied, user_not_found)';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.bulk_user_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.bulk_user_job_items ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.bulk_user_jobs
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.bulk_user_job_items
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte a identidade visual e os modelos de email dos tenants
+ */
+
+DROP TABLE IF EXISTS iam.email_templates;
+DROP TABLE IF EXISTS iam.tenant_branding;
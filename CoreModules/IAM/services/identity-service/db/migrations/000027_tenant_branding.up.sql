@@ -0,0 +1,63 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Identidade visual e modelos de email dos tenants
+ * Nome, logótipo, cores e contactos de suporte usados nos emails dos fluxos de autoatendimento
+ * e as versões dos modelos de cada email por idioma, com no máximo uma versão publicada.
+ */
+
+-- Tabela da Identidade Visual
+CREATE TABLE iam.tenant_branding (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    display_name VARCHAR(100) NOT NULL,
+    logo_url VARCHAR(2048),
+    primary_color VARCHAR(7),
+    secondary_color VARCHAR(7),
+    support_email VARCHAR(255),
+    support_url VARCHAR(2048),
+    footer_text VARCHAR(500),
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE iam.tenant_branding IS 'Identidade visual do tenant nos emails; sem registo é usada a identidade visual padrão';
+
+-- Tabela das Versões dos Modelos de Email
+-- As versões publicadas e arquivadas não são alteradas; a publicação de uma versão arquivada repõe-na
+CREATE TABLE iam.email_templates (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    kind VARCHAR(30) NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    description TEXT,
+    subject VARCHAR(200) NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_by UUID,
+    published_at TIMESTAMPTZ,
+    CONSTRAINT uq_email_templates_version UNIQUE (tenant_id, kind, locale, version),
+    CONSTRAINT ck_email_templates_kind CHECK (kind IN ('invite', 'password_reset', 'mfa_enrollment')),
+    CONSTRAINT ck_email_templates_status CHECK (status IN ('draft', 'published', 'archived'))
+);
+
+CREATE UNIQUE INDEX idx_email_templates_published ON iam.email_templates(tenant_id, kind, locale) WHERE status = 'published';
+
+COMMENT ON TABLE iam.email_templates IS 'Versões dos modelos de email dos fluxos de autoatendimento por idioma';
+COMMENT ON COLUMN iam.email_templates.html_body IS 'HTML sanitizado (html/template) com as variáveis do email';
+COMMENT ON COLUMN iam.email_templates.text_body IS 'Alternativa em texto simples (text/template)';
+COMMENT ON COLUMN iam.email_templates.published_at IS 'Última publicação da versão';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.tenant_branding ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.email_templates ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.tenant_branding
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.email_templates
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
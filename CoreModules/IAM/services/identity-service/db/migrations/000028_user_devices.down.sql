@@ -0,0 +1,9 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Reverte o registo dos dispositivos dos usuários
+ */
+
+DROP TABLE IF EXISTS iam.user_devices;
+DROP TABLE IF EXISTS iam.device_policies;
@@ -0,0 +1,74 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Registo dos dispositivos dos usuários
+ * Política de dispositivos de cada tenant e dispositivos vistos nos logins de cada usuário,
+ * com a confiança que dispensa o segundo fator e a sinalização dos dispositivos partilhados
+ * por várias contas do tenant.
+ */
+
+-- Tabela das Políticas de Dispositivos
+CREATE TABLE iam.device_policies (
+    tenant_id UUID PRIMARY KEY REFERENCES iam.tenants(id),
+    trust_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    trust_days INTEGER NOT NULL,
+    max_trusted_devices INTEGER NOT NULL,
+    reuse_threshold INTEGER NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ck_device_policies_trust_days CHECK (trust_days BETWEEN 1 AND 90),
+    CONSTRAINT ck_device_policies_max_trusted CHECK (max_trusted_devices BETWEEN 1 AND 20),
+    CONSTRAINT ck_device_policies_reuse_threshold CHECK (reuse_threshold BETWEEN 2 AND 100)
+);
+
+COMMENT ON TABLE iam.device_policies IS 'Limites da confiança em dispositivos de cada tenant; sem registo a confiança está desativada';
+COMMENT ON COLUMN iam.device_policies.reuse_threshold IS 'Número de contas a partir do qual um dispositivo partilhado é sinalizado';
+
+-- Tabela dos Dispositivos dos Usuários
+-- Apenas o HMAC do identificador do dispositivo é gravado; um dispositivo revogado fica no
+-- histórico e um login posterior a partir dele regista um dispositivo novo
+CREATE TABLE iam.user_devices (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES iam.tenants(id),
+    user_id UUID NOT NULL REFERENCES iam.users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    reuse_key VARCHAR(64),
+    identified BOOLEAN NOT NULL DEFAULT FALSE,
+    user_agent TEXT,
+    last_ip_address VARCHAR(45),
+    last_country VARCHAR(2),
+    last_method VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    login_count INTEGER NOT NULL DEFAULT 1,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    trusted_at TIMESTAMPTZ,
+    trust_expires_at TIMESTAMPTZ,
+    trusted_by UUID,
+    revoked_at TIMESTAMPTZ,
+    revoked_by UUID,
+    reuse_detected_at TIMESTAMPTZ,
+    CONSTRAINT ck_user_devices_status CHECK (status IN ('active', 'revoked')),
+    CONSTRAINT ck_user_devices_trust CHECK ((trusted_at IS NULL) = (trust_expires_at IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_user_devices_active ON iam.user_devices(tenant_id, user_id, fingerprint) WHERE status = 'active';
+CREATE INDEX idx_user_devices_user ON iam.user_devices(tenant_id, user_id, last_seen_at DESC);
+CREATE INDEX idx_user_devices_reuse_key ON iam.user_devices(tenant_id, reuse_key) WHERE reuse_key IS NOT NULL;
+
+COMMENT ON TABLE iam.user_devices IS 'Dispositivos vistos nos logins de cada usuário, com a confiança concedida e a revogação';
+COMMENT ON COLUMN iam.user_devices.fingerprint IS 'HMAC do dispositivo no contexto do usuário';
+COMMENT ON COLUMN iam.user_devices.reuse_key IS 'HMAC do identificador do dispositivo no tenant, para detetar a partilha entre contas; nulo nos dispositivos reconhecidos apenas pelo agente';
+COMMENT ON COLUMN iam.user_devices.reuse_detected_at IS 'Sinalização do dispositivo por ser usado por várias contas do tenant';
+
+-- Isolamento multi-tenant
+ALTER TABLE iam.device_policies ENABLE ROW LEVEL SECURITY;
+ALTER TABLE iam.user_devices ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation_policy ON iam.device_policies
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
+
+CREATE POLICY tenant_isolation_policy ON iam.user_devices
+    USING (tenant_id = current_setting('app.tenant_id')::UUID);
@@ -0,0 +1,198 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Migrações versionadas do esquema do Identity Service, embutidas no binário
+ * e aplicadas com golang-migrate. Inclui a deteção de divergência entre a
+ * versão do esquema na base de dados e a versão esperada pelo binário.
+ */
+
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Tabela de controlo das versões aplicadas (no esquema por omissão da ligação,
+// porque o esquema iam é criado pela primeira migração)
+const DefaultMigrationsTable = "schema_migrations"
+
+//go:embed *.sql
+var files embed.FS
+
+// Erros de divergência do esquema
+var (
+	ErrSchemaDirty    = errors.New("esquema inconsistente: uma migração foi interrompida e requer intervenção manual")
+	ErrSchemaOutdated = errors.New("esquema desatualizado: existem migrações pendentes")
+	ErrSchemaAhead    = errors.New("esquema mais recente do que o suportado por este binário")
+)
+
+// Status descreve o estado do esquema face às migrações embutidas
+type Status struct {
+	CurrentVersion uint   `json:"current_version"` // 0 quando nenhuma migração foi aplicada
+	LatestVersion  uint   `json:"latest_version"`
+	Dirty          bool   `json:"dirty"`
+	Pending        []uint `json:"pending"`
+}
+
+// Drift retorna o erro que descreve a divergência do esquema, ou nil se estiver alinhado
+func (s Status) Drift() error {
+	switch {
+	case s.Dirty:
+		return fmt.Errorf("%w (versão %d)", ErrSchemaDirty, s.CurrentVersion)
+	case s.CurrentVersion > s.LatestVersion:
+		return fmt.Errorf("%w (base de dados na versão %d, binário na versão %d)", ErrSchemaAhead, s.CurrentVersion, s.LatestVersion)
+	case len(s.Pending) > 0:
+		return fmt.Errorf("%w (base de dados na versão %d, binário na versão %d)", ErrSchemaOutdated, s.CurrentVersion, s.LatestVersion)
+	}
+	return nil
+}
+
+// Migrator aplica as migrações embutidas numa base de dados PostgreSQL
+type Migrator struct {
+	migrate  *migrate.Migrate
+	versions []uint
+}
+
+// New cria um Migrator ligado à base de dados indicada; a ligação é libertada por Close
+func New(databaseURL, migrationsTable string) (*Migrator, error) {
+	if migrationsTable == "" {
+		migrationsTable = DefaultMigrationsTable
+	}
+
+	versions, err := Versions()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("falha ao carregar migrações embutidas: %w", err)
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir a ligação à base de dados: %w", err)
+	}
+
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("falha ao preparar o driver de migração: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("falha ao criar o migrador: %w", err)
+	}
+
+	return &Migrator{migrate: m, versions: versions}, nil
+}
+
+// Versions retorna as versões das migrações embutidas, por ordem crescente
+func Versions() ([]uint, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar migrações embutidas: %w", err)
+	}
+
+	seen := make(map[uint]string, len(names))
+	versions := make([]uint, 0, len(names))
+	for _, name := range names {
+		parsed, err := source.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("nome de migração inválido %s: %w", name, err)
+		}
+		if previous, ok := seen[parsed.Version]; ok {
+			return nil, fmt.Errorf("versão de migração %d duplicada em %s e %s", parsed.Version, previous, name)
+		}
+		seen[parsed.Version] = name
+		versions = append(versions, parsed.Version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// Up aplica todas as migrações pendentes
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down reverte as últimas steps migrações aplicadas
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("número de migrações a reverter deve ser positivo: %d", steps)
+	}
+	return ignoreNoChange(m.migrate.Steps(-steps))
+}
+
+// Goto migra o esquema para a versão indicada, aplicando ou revertendo migrações
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.migrate.Migrate(version))
+}
+
+// Force marca a versão indicada como aplicada sem executar migrações, limpando o estado inconsistente
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Status retorna o estado do esquema face às migrações embutidas
+func (m *Migrator) Status() (Status, error) {
+	status := Status{Pending: []uint{}}
+	if len(m.versions) > 0 {
+		status.LatestVersion = m.versions[len(m.versions)-1]
+	}
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, fmt.Errorf("falha ao obter a versão do esquema: %w", err)
+	}
+	status.CurrentVersion = version
+	status.Dirty = dirty
+
+	for _, v := range m.versions {
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+	return status, nil
+}
+
+// CheckDrift retorna um erro se o esquema não corresponder à versão esperada pelo binário
+func (m *Migrator) CheckDrift() error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	return status.Drift()
+}
+
+// Close liberta a fonte embutida e a ligação à base de dados
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.migrate.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// ignoreNoChange trata a ausência de migrações a executar como sucesso
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
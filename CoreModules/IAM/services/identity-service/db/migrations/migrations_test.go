@@ -0,0 +1,64 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes das migrações embutidas e da deteção de divergência do esquema
+ */
+
+package migrations
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionsAreSequential(t *testing.T) {
+	versions, err := Versions()
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+
+	for i, version := range versions {
+		assert.Equal(t, uint(i+1), version, "migrações devem ser numeradas sem lacunas")
+	}
+}
+
+func TestEveryUpMigrationHasDown(t *testing.T) {
+	ups, err := fs.Glob(files, "*.up.sql")
+	require.NoError(t, err)
+
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		_, err := fs.Stat(files, down)
+		assert.NoError(t, err, "migração %s sem reversão", up)
+	}
+}
+
+func TestStatusDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		status Status
+		want   error
+	}{
+		{"alinhado", Status{CurrentVersion: 5, LatestVersion: 5}, nil},
+		{"pendente", Status{CurrentVersion: 3, LatestVersion: 5, Pending: []uint{4, 5}}, ErrSchemaOutdated},
+		{"vazio", Status{LatestVersion: 5, Pending: []uint{1, 2, 3, 4, 5}}, ErrSchemaOutdated},
+		{"adiantado", Status{CurrentVersion: 6, LatestVersion: 5}, ErrSchemaAhead},
+		{"inconsistente", Status{CurrentVersion: 5, LatestVersion: 5, Dirty: true}, ErrSchemaDirty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.Drift()
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.want), "erro inesperado: %v", err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package resolvers
 
 import (
 	"github.com/innovabiz/iam/internal/application/services"
+	"innovabiz/iam/identity-service/internal/application"
 	"github.com/innovabiz/iam/internal/domain/repositories"
 	"github.com/innovabiz/iam/internal/infrastructure/auth"
 	"github.com/innovabiz/iam/internal/infrastructure/observability"
@@ -22,6 +23,12 @@ type Resolver struct {
 	permissionService  services.PermissionService
 	securityService    services.SecurityService
 	
+	// Políticas de acesso às chaves dos metadados dos papéis; nulas não restringem os metadados
+	roleMetadataPolicyService application.RoleMetadataPolicyService
+	
+	// Fluxo das alterações de papéis entregue às subscriptions; nulo desabilita as subscriptions de papéis
+	roleChangeFeed application.RoleChangeFeed
+	
 	// Repositórios para acesso direto quando necessário
 	userRepository     repositories.UserRepository
 	groupRepository    repositories.GroupRepository
@@ -0,0 +1,95 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/innovabiz/iam/internal/domain/model"
+	"github.com/innovabiz/iam/internal/domain/model/errors"
+	"github.com/innovabiz/iam/internal/infrastructure/auth"
+
+	"innovabiz/iam/identity-service/internal/application"
+	identitymodel "innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Este arquivo aplica aos resolvers de papéis as políticas de acesso às chaves dos metadados,
+// definidas no catálogo de permissões pelas permissões "roles:metadata.<chave>:read|write"
+
+// SetRoleMetadataPolicyService configura as políticas de acesso às chaves dos metadados dos papéis
+// Sem serviço configurado os metadados não têm chaves protegidas
+func (r *Resolver) SetRoleMetadataPolicyService(roleMetadataPolicyService application.RoleMetadataPolicyService) {
+	r.roleMetadataPolicyService = roleMetadataPolicyService
+}
+
+// roleMetadataAccess calcula as chaves protegidas dos metadados do tenant que o usuário autenticado
+// pode ler e alterar; um usuário de outro tenant não detém as permissões do catálogo do tenant
+// Retorna nil, sem restrições, quando as políticas não estão configuradas
+func (r *Resolver) roleMetadataAccess(ctx context.Context, authInfo *auth.AuthInfo, tenantID string) (*identitymodel.RoleMetadataAccess, error) {
+	if r.roleMetadataPolicyService == nil {
+		return nil, nil
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("tenant inválido: %w", err)
+	}
+	userUUID, err := uuid.Parse(authInfo.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("usuário inválido: %w", err)
+	}
+	if tenantID != authInfo.TenantID {
+		userUUID = uuid.Nil
+	}
+
+	return r.roleMetadataPolicyService.Access(ctx, tenantUUID, userUUID)
+}
+
+// redactRoleMetadata remove dos metadados dos papéis as chaves que o usuário autenticado não pode ler
+func (r *Resolver) redactRoleMetadata(ctx context.Context, authInfo *auth.AuthInfo, roles ...*model.Role) error {
+	accessByTenant := make(map[string]*identitymodel.RoleMetadataAccess)
+	for _, role := range roles {
+		if role == nil || len(role.Metadata) == 0 {
+			continue
+		}
+
+		access, ok := accessByTenant[role.TenantID]
+		if !ok {
+			var err error
+			access, err = r.roleMetadataAccess(ctx, authInfo, role.TenantID)
+			if err != nil {
+				r.logger.Error(ctx, "Failed to evaluate role metadata policies",
+					"error", err.Error(),
+					"tenant_id", role.TenantID)
+				return err
+			}
+			accessByTenant[role.TenantID] = access
+		}
+		role.Metadata = access.FilterReadable(role.Metadata)
+	}
+	return nil
+}
+
+// applyRoleMetadataWrite combina os metadados pedidos com os atuais (nil numa criação) segundo as políticas
+// Recusa a operação quando o pedido altera chaves que o usuário autenticado não pode alterar
+func (r *Resolver) applyRoleMetadataWrite(ctx context.Context, authInfo *auth.AuthInfo, tenantID string, current, requested map[string]interface{}) (map[string]interface{}, error) {
+	access, err := r.roleMetadataAccess(ctx, authInfo, tenantID)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to evaluate role metadata policies",
+			"error", err.Error(),
+			"tenant_id", tenantID)
+		return nil, err
+	}
+
+	metadata, denied := access.ApplyWrite(current, requested)
+	if len(denied) > 0 {
+		r.logger.Warn(ctx, "Permission denied for protected role metadata keys",
+			"requester_id", authInfo.UserID,
+			"tenant_id", tenantID,
+			"denied_keys", denied)
+		return nil, errors.NewForbiddenError("role_metadata_write",
+			fmt.Sprintf("Não possui permissão para alterar os campos protegidos dos metadados: %s", strings.Join(denied, ", ")))
+	}
+	return metadata, nil
+}
@@ -57,6 +57,13 @@ func (r *queryResolver) Role(ctx context.Context, id string) (*model.Role, error
 		return nil, errors.NewForbiddenError("cross_tenant_access", "Acesso a recursos de outro tenant não permitido")
 	}
 
+	// Remover as chaves protegidas dos metadados que o requisitante não pode ler
+	if err := r.redactRoleMetadata(ctx, authInfo, role); err != nil {
+		span.SetAttributes(attribute.Bool("success", false))
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
 	// Registrar acesso bem-sucedido
 	span.SetAttributes(attribute.Bool("success", true))
 
@@ -128,6 +135,13 @@ func (r *queryResolver) Roles(ctx context.Context, filter *model.RoleFilter, pag
 		return nil, err
 	}
 
+	// Remover as chaves protegidas dos metadados que o requisitante não pode ler
+	if err := r.redactRoleMetadata(ctx, authInfo, result.Items...); err != nil {
+		span.SetAttributes(attribute.Bool("success", false))
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
 	// Registrar acesso bem-sucedido
 	span.SetAttributes(attribute.Bool("success", true))
 	span.SetAttributes(attribute.Int("result.total_count", result.TotalCount))
@@ -192,6 +206,13 @@ func (r *queryResolver) RoleByCode(ctx context.Context, code string, tenantID *s
 		return nil, err
 	}
 
+	// Remover as chaves protegidas dos metadados que o requisitante não pode ler
+	if err := r.redactRoleMetadata(ctx, authInfo, role); err != nil {
+		span.SetAttributes(attribute.Bool("success", false))
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+
 	// Registrar acesso bem-sucedido
 	span.SetAttributes(attribute.Bool("success", true))
 
@@ -240,6 +261,17 @@ func (r *mutationResolver) CreateRole(ctx context.Context, input model.CreateRol
 		return nil, errors.NewForbiddenError("system_role_creation", "Não possui permissão para criar papéis de sistema")
 	}
 
+	// Recusar alterações às chaves protegidas dos metadados que o requisitante não pode alterar
+	if input.Metadata != nil {
+		metadata, err := r.applyRoleMetadataWrite(ctx, authInfo, input.TenantID, nil, input.Metadata)
+		if err != nil {
+			span.SetAttributes(attribute.Bool("success", false))
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return nil, err
+		}
+		input.Metadata = metadata
+	}
+
 	// Adicionar atributos ao span
 	span.SetAttributes(attribute.String("role.name", input.Name))
 	span.SetAttributes(attribute.String("role.code", input.Code))
@@ -278,6 +310,11 @@ func (r *mutationResolver) CreateRole(ctx context.Context, input model.CreateRol
 		})
 	}
 
+	// Remover da resposta as chaves protegidas dos metadados que o requisitante não pode ler
+	if err := r.redactRoleMetadata(ctx, authInfo, role); err != nil {
+		return nil, err
+	}
+
 	return role, nil
 }
 
@@ -329,6 +366,17 @@ func (r *mutationResolver) UpdateRole(ctx context.Context, id string, input mode
 		return nil, errors.NewForbiddenError("system_role_update", "Não possui permissão para modificar papéis de sistema")
 	}
 
+	// Recusar alterações às chaves protegidas dos metadados que o requisitante não pode alterar
+	if input.Metadata != nil {
+		metadata, err := r.applyRoleMetadataWrite(ctx, authInfo, existingRole.TenantID, existingRole.Metadata, input.Metadata)
+		if err != nil {
+			span.SetAttributes(attribute.Bool("success", false))
+			span.SetAttributes(attribute.String("error", err.Error()))
+			return nil, err
+		}
+		input.Metadata = metadata
+	}
+
 	// Atualizar papel via serviço
 	role, err := r.roleService.Update(ctx, id, &input)
 	if err != nil {
@@ -361,6 +409,11 @@ func (r *mutationResolver) UpdateRole(ctx context.Context, id string, input mode
 		})
 	}
 
+	// Remover da resposta as chaves protegidas dos metadados que o requisitante não pode ler
+	if err := r.redactRoleMetadata(ctx, authInfo, role); err != nil {
+		return nil, err
+	}
+
 	return role, nil
 }
 
@@ -0,0 +1,190 @@
+package resolvers
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/google/uuid"
+	"github.com/innovabiz/iam/internal/domain/model"
+	"github.com/innovabiz/iam/internal/domain/model/errors"
+	"github.com/innovabiz/iam/internal/infrastructure/auth"
+	"go.opentelemetry.io/otel/attribute"
+
+	"innovabiz/iam/identity-service/internal/application"
+	identitymodel "innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Este arquivo expõe as subscriptions das alterações de papéis e das suas atribuições,
+// alimentadas pelo fluxo RoleChangeFeed a partir dos eventos de domínio do barramento
+
+// SetRoleChangeFeed configura o fluxo das alterações de papéis usado pelas subscriptions
+// Sem fluxo configurado as subscriptions de papéis são recusadas
+func (r *Resolver) SetRoleChangeFeed(roleChangeFeed application.RoleChangeFeed) {
+	r.roleChangeFeed = roleChangeFeed
+}
+
+// RoleChanged handle a subscription para alterações de papéis em tempo real
+func (r *subscriptionResolver) RoleChanged(ctx context.Context, roleID *string, types []model.RoleChangeType) (<-chan *model.RoleChangeEvent, error) {
+	// Iniciar span para observabilidade
+	ctx, span := r.tracer.Start(ctx, "resolvers.subscription.roleChanged")
+	defer span.End()
+
+	filter := identitymodel.RoleChangeFilter{}
+	for _, changeType := range types {
+		filter.Types = append(filter.Types, identitymodel.RoleChangeType(changeType))
+	}
+
+	events, err := r.subscribeRoleChanges(ctx, "roleChanged", roleID, nil, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Bool("success", true))
+	return events, nil
+}
+
+// RoleAssignmentChanged handle a subscription para atribuições e revogações de papéis em tempo real
+// Com userId recebe apenas as atribuições e revogações do papel a esse usuário
+func (r *subscriptionResolver) RoleAssignmentChanged(ctx context.Context, roleID *string, userID *string) (<-chan *model.RoleChangeEvent, error) {
+	// Iniciar span para observabilidade
+	ctx, span := r.tracer.Start(ctx, "resolvers.subscription.roleAssignmentChanged")
+	defer span.End()
+
+	filter := identitymodel.RoleChangeFilter{
+		Types: []identitymodel.RoleChangeType{
+			identitymodel.RoleChangePermissionsAssigned,
+			identitymodel.RoleChangePermissionsRevoked,
+			identitymodel.RoleChangeUsersAssigned,
+			identitymodel.RoleChangeUsersRevoked,
+		},
+	}
+	if userID != nil {
+		// As alterações de permissões não identificam usuários
+		filter.Types = []identitymodel.RoleChangeType{identitymodel.RoleChangeUsersAssigned, identitymodel.RoleChangeUsersRevoked}
+	}
+
+	events, err := r.subscribeRoleChanges(ctx, "roleAssignmentChanged", roleID, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Bool("success", true))
+	return events, nil
+}
+
+// subscribeRoleChanges autoriza a subscription, completa o filtro e converte as notificações do fluxo
+func (r *subscriptionResolver) subscribeRoleChanges(ctx context.Context, operation string, roleID, userID *string, filter identitymodel.RoleChangeFilter) (<-chan *model.RoleChangeEvent, error) {
+	// Obter contexto de autenticação
+	authInfo := auth.GetAuthInfoFromContext(ctx)
+	if authInfo == nil {
+		return nil, errors.ErrUnauthorized
+	}
+
+	if r.roleChangeFeed == nil || (r.config != nil && !r.config.EnableSubscriptions) {
+		return nil, errors.NewBusinessError("subscriptions_disabled", "Subscriptions de papéis não estão habilitadas")
+	}
+
+	// Verificar permissão para acompanhar os papéis
+	if !authInfo.HasPermission("IAM:ReadRole") {
+		r.logger.Warn(ctx, "Permission denied for role change subscription",
+			"requester_id", authInfo.UserID,
+			"operation", operation)
+		return nil, errors.NewForbiddenError("role_subscription_denied", "Permissão insuficiente para assinar alterações de papéis")
+	}
+
+	subscriber := identitymodel.RoleChangeSubscriber{}
+	var err error
+	if subscriber.TenantID, err = uuid.Parse(authInfo.TenantID); err != nil {
+		return nil, errors.ErrUnauthorized
+	}
+	if subscriber.UserID, err = uuid.Parse(authInfo.UserID); err != nil {
+		return nil, errors.ErrUnauthorized
+	}
+
+	// Forçar filtro de tenant para garantir isolamento, exceto para administradores com permissão especial
+	if !authInfo.HasPermission("IAM:CrossTenantAccess") {
+		filter.TenantID = subscriber.TenantID
+	}
+	if roleID != nil {
+		id, err := uuid.Parse(*roleID)
+		if err != nil {
+			return nil, errors.NewBusinessError("invalid_role_id", "ID de papel inválido")
+		}
+		filter.RoleID = &id
+	}
+	if userID != nil {
+		id, err := uuid.Parse(*userID)
+		if err != nil {
+			return nil, errors.NewBusinessError("invalid_user_id", "ID de usuário inválido")
+		}
+		filter.UserID = &id
+	}
+
+	notifications, err := r.roleChangeFeed.Subscribe(ctx, subscriber, filter)
+	if err != nil {
+		if stderrors.Is(err, application.ErrRoleChangeSubscriptionLimit) {
+			r.logger.Warn(ctx, "Role change subscription limit reached",
+				"requester_id", authInfo.UserID,
+				"tenant_id", authInfo.TenantID)
+			return nil, errors.NewBusinessError("subscription_limit_reached", "Limite de subscriptions de papéis atingido")
+		}
+		r.logger.Error(ctx, "Failed to subscribe to role changes",
+			"error", err.Error(),
+			"requester_id", authInfo.UserID)
+		return nil, err
+	}
+
+	// Logging para auditoria
+	r.logger.Info(ctx, "GraphQL subscription: "+operation,
+		"filter", filter,
+		"requester_id", authInfo.UserID,
+		"tenant_id", authInfo.TenantID)
+
+	// Converter as notificações até o fluxo fechar o canal, ao desconectar o cliente ou por atraso
+	events := make(chan *model.RoleChangeEvent)
+	go func() {
+		defer close(events)
+		for notification := range notifications {
+			select {
+			case events <- roleChangeEventToGraphQL(notification):
+			case <-ctx.Done():
+			}
+		}
+		r.logger.Info(ctx, "Role change subscription ended",
+			"requester_id", authInfo.UserID,
+			"operation", operation)
+	}()
+
+	return events, nil
+}
+
+// roleChangeEventToGraphQL converte uma notificação do fluxo no modelo GraphQL
+func roleChangeEventToGraphQL(notification *identitymodel.RoleChangeNotification) *model.RoleChangeEvent {
+	event := &model.RoleChangeEvent{
+		ID:            notification.ID.String(),
+		Type:          model.RoleChangeType(notification.Type),
+		TenantID:      notification.TenantID.String(),
+		RoleID:        notification.RoleID.String(),
+		RoleCode:      notification.RoleCode,
+		IsActive:      notification.IsActive,
+		HardDeleted:   notification.HardDeleted,
+		PermissionIds: make([]string, 0, len(notification.PermissionIDs)),
+		UserIds:       make([]string, 0, len(notification.UserIDs)),
+		Timestamp:     notification.OccurredAt,
+	}
+	if notification.RoleName != "" {
+		roleName := notification.RoleName
+		event.RoleName = &roleName
+	}
+	for _, permissionID := range notification.PermissionIDs {
+		event.PermissionIds = append(event.PermissionIds, permissionID.String())
+	}
+	for _, userID := range notification.UserIDs {
+		event.UserIds = append(event.UserIds, userID.String())
+	}
+	if notification.ActorID != nil {
+		actorID := notification.ActorID.String()
+		event.ActorID = &actorID
+	}
+	return event
+}
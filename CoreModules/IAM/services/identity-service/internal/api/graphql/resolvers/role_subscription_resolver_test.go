@@ -0,0 +1,296 @@
+package resolvers
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/innovabiz/iam/internal/domain/model"
+	"github.com/innovabiz/iam/internal/domain/model/errors"
+	"github.com/innovabiz/iam/internal/infrastructure/auth"
+	"github.com/innovabiz/iam/internal/infrastructure/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	identitymodel "innovabiz/iam/identity-service/internal/domain/model"
+)
+
+var (
+	subscriptionTenantID = uuid.MustParse("5b0e3a8e-6f5e-4d0a-8f5e-3b1f2a9c7d11")
+	subscriptionUserID   = uuid.MustParse("9c1f1f0e-4b1e-4a53-9d43-1c1a0f1e2d3c")
+	subscriptionRoleID   = uuid.MustParse("3d6f0a52-8c1b-4f7e-a2d9-6b4e1c0f5a27")
+)
+
+// fakeRoleChangeFeed regista as subscriptions abertas e entrega as notificações do canal partilhado
+type fakeRoleChangeFeed struct {
+	mu            sync.Mutex
+	subscribers   []identitymodel.RoleChangeSubscriber
+	filters       []identitymodel.RoleChangeFilter
+	notifications chan *identitymodel.RoleChangeNotification
+	err           error
+}
+
+func newFakeRoleChangeFeed() *fakeRoleChangeFeed {
+	return &fakeRoleChangeFeed{notifications: make(chan *identitymodel.RoleChangeNotification, 1)}
+}
+
+func (f *fakeRoleChangeFeed) Subscribe(ctx context.Context, subscriber identitymodel.RoleChangeSubscriber, filter identitymodel.RoleChangeFilter) (<-chan *identitymodel.RoleChangeNotification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.subscribers = append(f.subscribers, subscriber)
+	f.filters = append(f.filters, filter)
+	return f.notifications, nil
+}
+
+func (f *fakeRoleChangeFeed) HandleEvent(ctx context.Context, evt event.Event) error {
+	return nil
+}
+
+func (f *fakeRoleChangeFeed) ActiveSubscriptions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.filters)
+}
+
+// lastFilter retorna o filtro da última subscription aberta
+func (f *fakeRoleChangeFeed) lastFilter(t *testing.T) identitymodel.RoleChangeFilter {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.filters, "nenhuma subscription aberta no fluxo")
+	return f.filters[len(f.filters)-1]
+}
+
+// discardLogger descarta as mensagens registadas pelo resolver
+type discardLogger struct {
+	observability.Logger
+}
+
+func (discardLogger) Info(ctx context.Context, msg string, keysAndValues ...interface{})  {}
+func (discardLogger) Warn(ctx context.Context, msg string, keysAndValues ...interface{})  {}
+func (discardLogger) Error(ctx context.Context, msg string, keysAndValues ...interface{}) {}
+
+// newRoleSubscriptionResolver cria o resolver das subscriptions com o fluxo indicado
+func newRoleSubscriptionResolver(feed application.RoleChangeFeed) *subscriptionResolver {
+	resolver := &Resolver{
+		tracer: noop.NewTracerProvider().Tracer(""),
+		logger: discardLogger{},
+		config: DefaultConfig(),
+	}
+	if feed != nil {
+		resolver.SetRoleChangeFeed(feed)
+	}
+	return &subscriptionResolver{Resolver: resolver}
+}
+
+// subscriptionContext cria o contexto de um usuário autenticado com as permissões indicadas
+func subscriptionContext(permissions ...string) context.Context {
+	return auth.EnrichContext(context.Background(), &auth.AuthInfo{
+		UserID:      subscriptionUserID.String(),
+		TenantID:    subscriptionTenantID.String(),
+		Permissions: permissions,
+	})
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestRoleSubscriptionsRequireAuthentication(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+
+	_, err := resolver.RoleChanged(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, errors.ErrUnauthorized)
+
+	_, err = resolver.RoleAssignmentChanged(context.Background(), nil, nil)
+	assert.ErrorIs(t, err, errors.ErrUnauthorized)
+
+	// Identificadores inválidos no contexto de autenticação não abrem subscriptions
+	ctx := auth.EnrichContext(context.Background(), &auth.AuthInfo{
+		UserID:      subscriptionUserID.String(),
+		TenantID:    "tenant-desconhecido",
+		Permissions: []string{"IAM:ReadRole"},
+	})
+	_, err = resolver.RoleChanged(ctx, nil, nil)
+	assert.ErrorIs(t, err, errors.ErrUnauthorized)
+
+	assert.Zero(t, feed.ActiveSubscriptions())
+}
+
+func TestRoleSubscriptionsDisabled(t *testing.T) {
+	ctx := subscriptionContext("IAM:ReadRole")
+	disabled := errors.NewBusinessError("subscriptions_disabled", "Subscriptions de papéis não estão habilitadas")
+
+	_, err := newRoleSubscriptionResolver(nil).RoleChanged(ctx, nil, nil)
+	assert.Equal(t, disabled, err, "sem fluxo configurado")
+
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+	resolver.config.EnableSubscriptions = false
+	_, err = resolver.RoleAssignmentChanged(ctx, nil, nil)
+	assert.Equal(t, disabled, err, "subscriptions desabilitadas na configuração")
+	assert.Zero(t, feed.ActiveSubscriptions())
+}
+
+func TestRoleSubscriptionsRequireReadRolePermission(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+	denied := errors.NewForbiddenError("role_subscription_denied", "Permissão insuficiente para assinar alterações de papéis")
+
+	for _, permissions := range [][]string{nil, {"IAM:ReadUser"}, {"IAM:CrossTenantAccess"}} {
+		ctx := subscriptionContext(permissions...)
+
+		_, err := resolver.RoleChanged(ctx, nil, nil)
+		assert.Equal(t, denied, err, permissions)
+
+		_, err = resolver.RoleAssignmentChanged(ctx, nil, nil)
+		assert.Equal(t, denied, err, permissions)
+	}
+	assert.Zero(t, feed.ActiveSubscriptions())
+}
+
+func TestRoleChangedForcesTenantFilter(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+
+	_, err := resolver.RoleChanged(subscriptionContext("IAM:ReadRole"), stringPtr(subscriptionRoleID.String()),
+		[]model.RoleChangeType{model.RoleChangeTypeRoleCreated, model.RoleChangeTypeRoleDeleted})
+	require.NoError(t, err)
+
+	filter := feed.lastFilter(t)
+	assert.Equal(t, subscriptionTenantID, filter.TenantID, "sem acesso entre tenants o filtro fica restrito ao tenant do usuário")
+	require.NotNil(t, filter.RoleID)
+	assert.Equal(t, subscriptionRoleID, *filter.RoleID)
+	assert.Nil(t, filter.UserID)
+	assert.Equal(t, []identitymodel.RoleChangeType{identitymodel.RoleChangeCreated, identitymodel.RoleChangeDeleted}, filter.Types)
+	assert.Equal(t, identitymodel.RoleChangeSubscriber{TenantID: subscriptionTenantID, UserID: subscriptionUserID}, feed.subscribers[0])
+
+	// Com IAM:CrossTenantAccess a subscription recebe as alterações de todos os tenants
+	_, err = resolver.RoleChanged(subscriptionContext("IAM:ReadRole", "IAM:CrossTenantAccess"), nil, nil)
+	require.NoError(t, err)
+
+	filter = feed.lastFilter(t)
+	assert.Equal(t, uuid.Nil, filter.TenantID)
+	assert.Nil(t, filter.RoleID)
+	assert.Empty(t, filter.Types)
+	assert.Equal(t, subscriptionTenantID, feed.subscribers[1].TenantID, "as quotas continuam contadas no tenant do usuário")
+}
+
+func TestRoleAssignmentChangedFilter(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+	ctx := subscriptionContext("IAM:ReadRole")
+
+	_, err := resolver.RoleAssignmentChanged(ctx, stringPtr(subscriptionRoleID.String()), nil)
+	require.NoError(t, err)
+
+	filter := feed.lastFilter(t)
+	assert.Equal(t, subscriptionTenantID, filter.TenantID)
+	assert.Equal(t, []identitymodel.RoleChangeType{
+		identitymodel.RoleChangePermissionsAssigned,
+		identitymodel.RoleChangePermissionsRevoked,
+		identitymodel.RoleChangeUsersAssigned,
+		identitymodel.RoleChangeUsersRevoked,
+	}, filter.Types)
+	assert.Nil(t, filter.UserID)
+
+	// Com userId apenas as atribuições e revogações de usuários são entregues
+	userID := uuid.MustParse("7e2d4c19-0a3b-4f6e-8d21-5c9b0e7a1f42")
+	_, err = resolver.RoleAssignmentChanged(ctx, nil, stringPtr(userID.String()))
+	require.NoError(t, err)
+
+	filter = feed.lastFilter(t)
+	assert.Equal(t, subscriptionTenantID, filter.TenantID)
+	assert.Equal(t, []identitymodel.RoleChangeType{identitymodel.RoleChangeUsersAssigned, identitymodel.RoleChangeUsersRevoked}, filter.Types)
+	require.NotNil(t, filter.UserID)
+	assert.Equal(t, userID, *filter.UserID)
+	assert.Nil(t, filter.RoleID)
+}
+
+func TestRoleSubscriptionsRejectInvalidIDs(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+	ctx := subscriptionContext("IAM:ReadRole")
+
+	_, err := resolver.RoleChanged(ctx, stringPtr("papel-1"), nil)
+	assert.Equal(t, errors.NewBusinessError("invalid_role_id", "ID de papel inválido"), err)
+
+	_, err = resolver.RoleAssignmentChanged(ctx, nil, stringPtr("usuario-1"))
+	assert.Equal(t, errors.NewBusinessError("invalid_user_id", "ID de usuário inválido"), err)
+
+	assert.Zero(t, feed.ActiveSubscriptions())
+}
+
+func TestRoleSubscriptionsFeedErrors(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+	ctx := subscriptionContext("IAM:ReadRole")
+
+	feed.err = application.ErrRoleChangeSubscriptionLimit
+	_, err := resolver.RoleChanged(ctx, nil, nil)
+	assert.Equal(t, errors.NewBusinessError("subscription_limit_reached", "Limite de subscriptions de papéis atingido"), err)
+
+	feed.err = stderrors.New("barramento indisponível")
+	_, err = resolver.RoleAssignmentChanged(ctx, nil, nil)
+	assert.Equal(t, feed.err, err)
+}
+
+func TestRoleChangedDeliversEvents(t *testing.T) {
+	feed := newFakeRoleChangeFeed()
+	resolver := newRoleSubscriptionResolver(feed)
+
+	events, err := resolver.RoleChanged(subscriptionContext("IAM:ReadRole"), nil, nil)
+	require.NoError(t, err)
+
+	actorID := uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+	permissionID := uuid.MustParse("2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e")
+	occurredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	feed.notifications <- &identitymodel.RoleChangeNotification{
+		ID:            uuid.MustParse("4c5d6e7f-8a9b-4c0d-9e1f-2a3b4c5d6e7f"),
+		Type:          identitymodel.RoleChangePermissionsAssigned,
+		TenantID:      subscriptionTenantID,
+		RoleID:        subscriptionRoleID,
+		RoleCode:      "CREDIT_ANALYST",
+		PermissionIDs: []uuid.UUID{permissionID},
+		ActorID:       &actorID,
+		OccurredAt:    occurredAt,
+	}
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok)
+		assert.Equal(t, "4c5d6e7f-8a9b-4c0d-9e1f-2a3b4c5d6e7f", event.ID)
+		assert.Equal(t, model.RoleChangeTypePermissionsAssigned, event.Type)
+		assert.Equal(t, subscriptionTenantID.String(), event.TenantID)
+		assert.Equal(t, subscriptionRoleID.String(), event.RoleID)
+		assert.Equal(t, "CREDIT_ANALYST", event.RoleCode)
+		assert.Nil(t, event.RoleName)
+		assert.Equal(t, []string{permissionID.String()}, event.PermissionIds)
+		assert.Equal(t, []string{}, event.UserIds)
+		require.NotNil(t, event.ActorID)
+		assert.Equal(t, actorID.String(), *event.ActorID)
+		assert.Equal(t, occurredAt, event.Timestamp)
+	case <-time.After(time.Second):
+		t.Fatal("evento não recebido")
+	}
+
+	// O canal da subscription fecha quando o fluxo fecha o canal das notificações
+	close(feed.notifications)
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription não encerrada")
+	}
+}
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos dos pedidos de acesso
+var (
+	ErrAccessRequestNotFound              = model.ErrAccessRequestNotFound
+	ErrInvalidAccessRequest               = model.ErrInvalidAccessRequest
+	ErrAccessRequestJustificationRequired = model.ErrAccessRequestJustificationRequired
+	ErrAccessRequestNotPending            = model.ErrAccessRequestNotPending
+	ErrAccessRequestConflict              = model.ErrAccessRequestConflict
+	ErrDuplicateAccessRequest             = model.ErrDuplicateAccessRequest
+	ErrAccessAlreadyGranted               = model.ErrAccessAlreadyGranted
+	ErrAccessRequestSelfApproval          = model.ErrAccessRequestSelfApproval
+	ErrAccessRequestApproverNotAuthorized = model.ErrAccessRequestApproverNotAuthorized
+	ErrAccessRequestNotRequester          = model.ErrAccessRequestNotRequester
+	ErrAccessApproverNotFound             = model.ErrAccessApproverNotFound
+	ErrInvalidAccessApprover              = model.ErrInvalidAccessApprover
+)
+
+// SubmitAccessRequest representa o pedido de uma função ou permissão pelo próprio usuário
+type SubmitAccessRequest struct {
+	TenantID      uuid.UUID                 `json:"tenant_id"`
+	RequesterID   uuid.UUID                 `json:"requester_id"`
+	TargetType    model.AccessRequestTarget `json:"target_type"`
+	TargetID      uuid.UUID                 `json:"target_id"`
+	Justification string                    `json:"justification"`
+	ExpiresAt     *time.Time                `json:"expires_at,omitempty"`
+}
+
+// AccessRequestDecision representa a aprovação ou rejeição de um pedido por um aprovador
+type AccessRequestDecision struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	RequestID  uuid.UUID `json:"request_id"`
+	ApproverID uuid.UUID `json:"approver_id"`
+	Reason     string    `json:"reason"`
+	// Validade da concessão; substitui a validade pedida quando informada
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AccessRequestSLAMetrics resume o cumprimento do prazo de decisão dos pedidos de um tenant
+// As durações são expressas em segundos
+type AccessRequestSLAMetrics struct {
+	TenantID                   uuid.UUID `json:"tenant_id"`
+	SLASeconds                 int64     `json:"sla_seconds"`
+	PendingCount               int       `json:"pending_count"`
+	PendingOverSLA             int       `json:"pending_over_sla"`
+	OldestPendingSeconds       int64     `json:"oldest_pending_seconds"`
+	WindowStart                time.Time `json:"window_start"`
+	DecidedCount               int       `json:"decided_count"`
+	DecidedWithinSLA           int       `json:"decided_within_sla"`
+	AverageDecisionTimeSeconds int64     `json:"average_decision_time_seconds"`
+	MaxDecisionTimeSeconds     int64     `json:"max_decision_time_seconds"`
+	GeneratedAt                time.Time `json:"generated_at"`
+}
+
+// AccessRequestService define a interface de serviço para os pedidos de acesso em autoatendimento
+type AccessRequestService interface {
+	// Submit regista um pedido pendente após validar o destino e o acesso atual do requerente
+	Submit(ctx context.Context, req *SubmitAccessRequest) (*model.AccessRequest, error)
+
+	// Get recupera um pedido pelo ID
+	Get(ctx context.Context, tenantID, requestID uuid.UUID) (*model.AccessRequest, error)
+
+	// ListByRequester recupera os pedidos feitos pelo usuário
+	ListByRequester(ctx context.Context, tenantID, requesterID uuid.UUID) ([]*model.AccessRequest, error)
+
+	// ListPendingForApprover recupera os pedidos pendentes que o aprovador pode decidir
+	ListPendingForApprover(ctx context.Context, tenantID, approverID uuid.UUID) ([]*model.AccessRequest, error)
+
+	// Approve aprova o pedido e concede o acesso ao requerente
+	Approve(ctx context.Context, decision *AccessRequestDecision) (*model.AccessRequest, error)
+
+	// Reject rejeita o pedido
+	Reject(ctx context.Context, decision *AccessRequestDecision) (*model.AccessRequest, error)
+
+	// Cancel retira um pedido pendente a pedido do requerente
+	Cancel(ctx context.Context, tenantID, requestID, requesterID uuid.UUID) (*model.AccessRequest, error)
+
+	// AddApprover atribui a um usuário a autoridade para decidir pedidos
+	AddApprover(ctx context.Context, approver *model.AccessRequestApprover) (*model.AccessRequestApprover, error)
+
+	// RemoveApprover retira a autoridade de um aprovador
+	RemoveApprover(ctx context.Context, tenantID, approverID uuid.UUID) error
+
+	// ListApprovers recupera os aprovadores do tenant
+	ListApprovers(ctx context.Context, tenantID uuid.UUID) ([]*model.AccessRequestApprover, error)
+
+	// GetSLAMetrics calcula as métricas de prazo dos pedidos pendentes e decididos recentemente
+	GetSLAMetrics(ctx context.Context, tenantID uuid.UUID) (*AccessRequestSLAMetrics, error)
+}
@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da ligação de contas
+var (
+	ErrAccountLinkCandidateNotFound = model.ErrAccountLinkCandidateNotFound
+	ErrAccountLinkCandidateClosed   = model.ErrAccountLinkCandidateClosed
+	ErrLinkedIdentityNotFound       = model.ErrLinkedIdentityNotFound
+	ErrIdentityAlreadyLinked        = model.ErrIdentityAlreadyLinked
+	ErrLastLinkedIdentity           = model.ErrLastLinkedIdentity
+	ErrReauthenticationRequired     = model.ErrReauthenticationRequired
+	ErrInvalidAccountLinkFilter     = model.ErrInvalidAccountLinkFilter
+)
+
+// DetectAccountLinkRequest descreve um login federado com um NameID ainda não ligado a nenhum usuário
+type DetectAccountLinkRequest struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	ProviderID uuid.UUID `json:"provider_id"`
+	Subject    string    `json:"subject"`
+	Email      string    `json:"email"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+}
+
+// AccountLinkCandidateDetector deteta, nos logins federados de NameIDs desconhecidos, a conta local
+// com o mesmo email verificado. A federação SAML usa-o antes do provisionamento automático
+type AccountLinkCandidateDetector interface {
+	// DetectCandidate regista um candidato à ligação quando o email corresponde a uma conta local
+	// ativa com email verificado; retorna nil quando não há conta a ligar
+	DetectCandidate(ctx context.Context, req *DetectAccountLinkRequest) (*model.AccountLinkCandidate, error)
+}
+
+// LinkAccountRequest representa a ligação de um candidato à conta do usuário autenticado
+// AuthenticatedAt é o instante da última autenticação do usuário, que tem de ser recente
+type LinkAccountRequest struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	UserID          uuid.UUID `json:"user_id"`
+	CandidateID     uuid.UUID `json:"candidate_id"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+	IPAddress       string    `json:"ip_address,omitempty"`
+}
+
+// UnlinkAccountRequest representa a remoção de uma identidade federada da conta do usuário autenticado
+type UnlinkAccountRequest struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	UserID          uuid.UUID `json:"user_id"`
+	IdentityID      uuid.UUID `json:"identity_id"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+	IPAddress       string    `json:"ip_address,omitempty"`
+}
+
+// AccountLinkService define a interface de serviço para a ligação de contas entre fornecedores de identidade
+type AccountLinkService interface {
+	AccountLinkCandidateDetector
+
+	// ListLinkedIdentities recupera os métodos de login do usuário
+	ListLinkedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LinkedIdentity, error)
+
+	// ListCandidates recupera os candidatos à ligação pendentes do usuário
+	ListCandidates(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.AccountLinkCandidate, error)
+
+	// Link liga o candidato à conta do usuário, que tem de se ter autenticado recentemente
+	Link(ctx context.Context, req *LinkAccountRequest) (*model.LinkedIdentity, error)
+
+	// DismissCandidate recusa um candidato à ligação do usuário
+	DismissCandidate(ctx context.Context, tenantID, userID, candidateID uuid.UUID, ipAddress string) (*model.AccountLinkCandidate, error)
+
+	// Unlink remove uma identidade federada da conta do usuário, que tem de se ter autenticado
+	// recentemente e manter pelo menos outro método de login
+	Unlink(ctx context.Context, req *UnlinkAccountRequest) error
+
+	// ListEvents recupera o registo de auditoria da ligação de contas do tenant
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.AccountLinkEventFilter) ([]*model.AccountLinkEvent, error)
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos dos incidentes de segurança
+var (
+	ErrSecurityIncidentNotFound          = model.ErrSecurityIncidentNotFound
+	ErrInvalidSecurityIncident           = model.ErrInvalidSecurityIncident
+	ErrInvalidSecurityIncidentTransition = model.ErrInvalidSecurityIncidentTransition
+	ErrSecurityIncidentConflict          = model.ErrSecurityIncidentConflict
+)
+
+// SecurityIncidentTriage representa a decisão da equipa de segurança sobre um incidente
+type SecurityIncidentTriage struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	IncidentID uuid.UUID `json:"incident_id"`
+	ActorID    uuid.UUID `json:"actor_id"`
+	Note       string    `json:"note"`
+}
+
+// AuditAnomalyService define a interface de serviço para a deteção de anomalias no fluxo de auditoria
+type AuditAnomalyService interface {
+	// Analyze avalia um evento de auditoria e abre incidentes para as anomalias detetadas
+	// Os eventos sem interesse para as regras são ignorados
+	Analyze(ctx context.Context, evt event.Event) ([]*model.SecurityIncident, error)
+
+	// ListIncidents recupera os incidentes do tenant que satisfazem o filtro
+	ListIncidents(ctx context.Context, tenantID uuid.UUID, filter model.SecurityIncidentFilter) ([]*model.SecurityIncident, error)
+
+	// GetIncident recupera um incidente pelo ID
+	GetIncident(ctx context.Context, tenantID, incidentID uuid.UUID) (*model.SecurityIncident, error)
+
+	// Acknowledge assinala que o incidente está a ser analisado
+	Acknowledge(ctx context.Context, triage *SecurityIncidentTriage) (*model.SecurityIncident, error)
+
+	// Resolve fecha o incidente após a correção
+	Resolve(ctx context.Context, triage *SecurityIncidentTriage) (*model.SecurityIncident, error)
+
+	// Dismiss fecha o incidente como falso positivo
+	Dismiss(ctx context.Context, triage *SecurityIncidentTriage) (*model.SecurityIncident, error)
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da consulta dos registos de auditoria
+var (
+	ErrInvalidAuditLogFilter = model.ErrInvalidAuditLogFilter
+)
+
+// AuditLogExportResult resume uma exportação CSV dos registos de auditoria
+type AuditLogExportResult struct {
+	Rows int `json:"rows"`
+	// Cursor a partir do qual a exportação continua quando atinge o limite de linhas
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// AuditLogService define a interface de serviço, apenas de leitura, dos registos de auditoria
+type AuditLogService interface {
+	// Search recupera uma página dos registos do tenant que satisfazem o filtro
+	// Sem limite indicado aplica-se o tamanho de página padrão, nunca acima do máximo configurado
+	Search(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) (*model.AuditLogPage, error)
+
+	// Export escreve em CSV os registos do tenant que satisfazem o filtro, do cursor em diante,
+	// até ao limite de linhas da exportação; o limite do filtro é ignorado
+	Export(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter, w io.Writer) (*AuditLogExportResult, error)
+}
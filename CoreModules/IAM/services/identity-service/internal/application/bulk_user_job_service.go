@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das operações em massa sobre usuários
+var (
+	ErrBulkUserJobNotFound = model.ErrBulkUserJobNotFound
+	ErrInvalidBulkUserJob  = model.ErrInvalidBulkUserJob
+	ErrBulkUserJobConflict = model.ErrBulkUserJobConflict
+)
+
+// BulkUserPayloadFormat representa o formato do conteúdo enviado com os usuários da tarefa
+type BulkUserPayloadFormat string
+
+// Formatos suportados
+const (
+	// Um objeto JSON por linha com os campos user_id, role_id, reason e expires_at
+	BulkUserPayloadNDJSON BulkUserPayloadFormat = "ndjson"
+	// CSV RFC 4180 com linha de cabeçalho; apenas a coluna user_id é obrigatória
+	BulkUserPayloadCSV BulkUserPayloadFormat = "csv"
+)
+
+// SubmitBulkUserJobRequest representa o envio de uma tarefa de operação em massa
+// A função, o motivo e a expiração aplicam-se aos itens que não os indicam
+type SubmitBulkUserJobRequest struct {
+	TenantID    uuid.UUID               `json:"tenant_id"`
+	RequestedBy uuid.UUID               `json:"requested_by"`
+	Operation   model.BulkUserOperation `json:"operation"`
+	RoleID      *uuid.UUID              `json:"role_id,omitempty"`
+	Reason      string                  `json:"reason,omitempty"`
+	ExpiresAt   *time.Time              `json:"expires_at,omitempty"`
+	Format      BulkUserPayloadFormat   `json:"format"`
+	Payload     io.Reader               `json:"-"`
+}
+
+// BulkUserJobItemPage representa uma página dos itens de uma tarefa, ordenados pela linha
+type BulkUserJobItemPage struct {
+	Items []*model.BulkUserJobItem `json:"items"`
+	// Linha a indicar em after para obter a página seguinte; ausente na última página
+	NextAfter int `json:"next_after,omitempty"`
+}
+
+// BulkUserJobRunResult resume uma passagem de execução das tarefas pendentes
+type BulkUserJobRunResult struct {
+	StartedAt time.Time `json:"started_at"`
+	Processed int       `json:"processed"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+}
+
+// BulkUserJobService define a interface de serviço para as operações em massa sobre usuários
+type BulkUserJobService interface {
+	// Submit valida o conteúdo enviado e regista uma tarefa pendente; a execução é feita pelo agendador
+	// Um item inválido recusa a tarefa inteira com model.ErrInvalidBulkUserJob, indicando a linha
+	Submit(ctx context.Context, req *SubmitBulkUserJobRequest) (*model.BulkUserJob, error)
+
+	// Get recupera uma tarefa pelo ID
+	Get(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error)
+
+	// List recupera as tarefas do tenant, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID uuid.UUID) ([]*model.BulkUserJob, error)
+
+	// ListItems recupera os itens da tarefa com linha posterior a after, opcionalmente filtrados pelo resultado
+	ListItems(ctx context.Context, tenantID, jobID uuid.UUID, status model.BulkUserItemStatus, after, limit int) (*BulkUserJobItemPage, error)
+
+	// Resume devolve à fila uma tarefa falhada ou concluída com erros, que volta a processar os itens falhados
+	Resume(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error)
+
+	// Run processa os itens pendentes da tarefa até à conclusão, à falha ou ao cancelamento do contexto
+	Run(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error)
+
+	// RunPending processa as tarefas pendentes ou interrompidas de todos os tenants
+	RunPending(ctx context.Context) (*BulkUserJobRunResult, error)
+}
@@ -0,0 +1,75 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos do registo de dispositivos
+var (
+	ErrDeviceNotFound       = model.ErrDeviceNotFound
+	ErrInvalidDevice        = model.ErrInvalidDevice
+	ErrInvalidDevicePolicy  = model.ErrInvalidDevicePolicy
+	ErrDeviceRevoked        = model.ErrDeviceRevoked
+	ErrDeviceTrustDisabled  = model.ErrDeviceTrustDisabled
+	ErrTrustedDeviceLimit   = model.ErrTrustedDeviceLimit
+	ErrDeviceTrustForbidden = model.ErrDeviceTrustForbidden
+)
+
+// DeviceTrustEvaluator indica se um login vem de um dispositivo de confiança
+// Os fluxos de MFA usam-no para dispensar o segundo fator sem depender do registo de dispositivos
+type DeviceTrustEvaluator interface {
+	// EvaluateDeviceTrust avalia o dispositivo do login contra a política do tenant
+	EvaluateDeviceTrust(ctx context.Context, event *model.LoginEvent) (*model.DeviceTrustDecision, error)
+}
+
+// UpdateDevicePolicyRequest representa a alteração da política de dispositivos do tenant
+type UpdateDevicePolicyRequest struct {
+	TenantID          uuid.UUID `json:"tenant_id"`
+	TrustEnabled      bool      `json:"trust_enabled"`
+	TrustDays         int       `json:"trust_days"`
+	MaxTrustedDevices int       `json:"max_trusted_devices"`
+	ReuseThreshold    int       `json:"reuse_threshold"`
+	UpdatedBy         uuid.UUID `json:"updated_by"`
+}
+
+// DeviceActionRequest identifica o dispositivo de um usuário alterado por um ator
+// O ator é o próprio usuário ou um administrador do tenant
+type DeviceActionRequest struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	DeviceID uuid.UUID `json:"device_id"`
+	ActorID  uuid.UUID `json:"actor_id"`
+}
+
+// DeviceService define a interface de serviço para o registo de dispositivos dos usuários
+type DeviceService interface {
+	LoginObserver
+	DeviceTrustEvaluator
+
+	// GetPolicy recupera a política de dispositivos do tenant
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.DevicePolicy, error)
+
+	// UpdatePolicy altera a política de dispositivos do tenant
+	// A confiança dos dispositivos já marcados mantém a validade com que foi concedida
+	UpdatePolicy(ctx context.Context, req *UpdateDevicePolicyRequest) (*model.DevicePolicy, error)
+
+	// ListDevices recupera os dispositivos do usuário, incluindo os revogados
+	ListDevices(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.Device, error)
+
+	// RenameDevice altera o nome apresentado de um dispositivo
+	RenameDevice(ctx context.Context, req *DeviceActionRequest, name string) (*model.Device, error)
+
+	// TrustDevice marca um dispositivo como de confiança pela validade da política do tenant
+	// Os dispositivos sem identificador ou usados por várias contas não podem ser de confiança
+	TrustDevice(ctx context.Context, req *DeviceActionRequest) (*model.Device, error)
+
+	// UntrustDevice retira a confiança num dispositivo; o login seguinte volta a exigir MFA
+	UntrustDevice(ctx context.Context, req *DeviceActionRequest) (*model.Device, error)
+
+	// RevokeDevice revoga um dispositivo e retira-lhe a confiança
+	RevokeDevice(ctx context.Context, req *DeviceActionRequest) (*model.Device, error)
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das contas de emergência
+var (
+	ErrEmergencyAccountNotFound    = model.ErrEmergencyAccountNotFound
+	ErrInvalidEmergencyAccount     = model.ErrInvalidEmergencyAccount
+	ErrEmergencyAccountConflict    = model.ErrEmergencyAccountConflict
+	ErrEmergencyActivationNotFound = model.ErrEmergencyActivationNotFound
+	ErrInvalidEmergencyActivation  = model.ErrInvalidEmergencyActivation
+	ErrEmergencyActivationOpen     = model.ErrEmergencyActivationOpen
+	ErrEmergencyActivationState    = model.ErrEmergencyActivationState
+	ErrEmergencyDualControl        = model.ErrEmergencyDualControl
+)
+
+// ProvisionEmergencyAccountRequest representa o registo de um usuário como conta de emergência
+// A partir do registo, os pedidos do usuário são recusados fora das ativações aprovadas
+type ProvisionEmergencyAccountRequest struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	ActorID     uuid.UUID `json:"actor_id"`
+}
+
+// RequestEmergencyActivationRequest representa o pedido de ativação de uma conta de emergência
+// Sem duração, é usada a duração padrão do serviço
+type RequestEmergencyActivationRequest struct {
+	TenantID      uuid.UUID     `json:"tenant_id"`
+	AccountID     uuid.UUID     `json:"account_id"`
+	IncidentRef   string        `json:"incident_ref"`
+	Justification string        `json:"justification"`
+	Duration      time.Duration `json:"duration"`
+	RequestedBy   uuid.UUID     `json:"requested_by"`
+}
+
+// ReviewEmergencyActivationRequest representa a revisão de uma ativação terminada
+type ReviewEmergencyActivationRequest struct {
+	TenantID     uuid.UUID                    `json:"tenant_id"`
+	ActivationID uuid.UUID                    `json:"activation_id"`
+	Outcome      model.EmergencyReviewOutcome `json:"outcome"`
+	Notes        string                       `json:"notes,omitempty"`
+	ReviewerID   uuid.UUID                    `json:"reviewer_id"`
+}
+
+// EmergencyAccessSweepResult resume uma execução das desativações automáticas
+type EmergencyAccessSweepResult struct {
+	StartedAt         time.Time `json:"started_at"`
+	Expired           int       `json:"expired"`
+	ApprovalsTimedOut int       `json:"approvals_timed_out"`
+	Failed            int       `json:"failed"`
+}
+
+// EmergencyAccessService define a interface de serviço para as contas de emergência (break-glass)
+type EmergencyAccessService interface {
+	// ProvisionAccount regista um usuário do tenant como conta de emergência selada
+	ProvisionAccount(ctx context.Context, req *ProvisionEmergencyAccountRequest) (*model.EmergencyAccount, error)
+
+	// ListAccounts recupera as contas de emergência do tenant
+	ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]*model.EmergencyAccount, error)
+
+	// RequestActivation pede a ativação de uma conta de emergência, que fica pendente de aprovação
+	RequestActivation(ctx context.Context, req *RequestEmergencyActivationRequest) (*model.EmergencyActivation, error)
+
+	// ApproveActivation aprova um pedido de ativação; o aprovador tem de ser diferente do requerente
+	ApproveActivation(ctx context.Context, tenantID, activationID, approverID uuid.UUID) (*model.EmergencyActivation, error)
+
+	// RejectActivation recusa um pedido de ativação pendente
+	RejectActivation(ctx context.Context, tenantID, activationID, actorID uuid.UUID, note string) (*model.EmergencyActivation, error)
+
+	// CloseActivation termina uma ativação antes de expirar
+	CloseActivation(ctx context.Context, tenantID, activationID, actorID uuid.UUID, reason string) (*model.EmergencyActivation, error)
+
+	// ReviewActivation regista a revisão de uma ativação terminada
+	ReviewActivation(ctx context.Context, req *ReviewEmergencyActivationRequest) (*model.EmergencyActivation, error)
+
+	// GetActivation recupera uma ativação do tenant
+	GetActivation(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyActivation, error)
+
+	// ListActivations recupera as ativações do tenant; status limita a um estado
+	ListActivations(ctx context.Context, tenantID uuid.UUID, status model.EmergencyActivationStatus) ([]*model.EmergencyActivation, error)
+
+	// ListActions recupera as ações gravadas durante uma ativação, a seguir a afterSequence
+	ListActions(ctx context.Context, tenantID, activationID uuid.UUID, afterSequence int64, limit int) ([]*model.EmergencyAction, error)
+
+	// VerifyActions verifica a integridade do registo encadeado das ações de uma ativação
+	VerifyActions(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyChainVerification, error)
+
+	// Authorize verifica um pedido autenticado: os pedidos das contas de emergência só são permitidos
+	// durante uma ativação em vigor e depois de ficarem gravados no registo da ativação
+	Authorize(ctx context.Context, req *model.EmergencyAccessRequest) (*model.EmergencyAccessDecision, error)
+
+	// RunScheduledDeactivations termina as ativações expiradas e recusa os pedidos não aprovados no prazo
+	RunScheduledDeactivations(ctx context.Context) (*EmergencyAccessSweepResult, error)
+}
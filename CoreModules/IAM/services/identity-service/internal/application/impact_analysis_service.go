@@ -0,0 +1,94 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MutationType define os tipos de alteração suportados pela análise de impacto
+type MutationType string
+
+const (
+	// MutationRevokePermission representa a revogação de uma permissão direta de uma função
+	MutationRevokePermission MutationType = "REVOKE_PERMISSION"
+
+	// MutationRemoveChildRole representa a remoção de uma função filha da hierarquia
+	MutationRemoveChildRole MutationType = "REMOVE_CHILD_ROLE"
+
+	// MutationDeactivateRole representa a desativação de uma função
+	MutationDeactivateRole MutationType = "DEACTIVATE_ROLE"
+
+	// MutationDeleteRole representa a exclusão de uma função
+	MutationDeleteRole MutationType = "DELETE_ROLE"
+)
+
+// Erros específicos da análise de impacto
+var (
+	ErrInvalidMutation = errors.New("alteração inválida para análise de impacto")
+)
+
+// ImpactAnalysisRequest representa uma alteração proposta a ser analisada sem aplicação
+type ImpactAnalysisRequest struct {
+	TenantID     uuid.UUID
+	RoleID       uuid.UUID
+	Mutation     MutationType
+	PermissionID *uuid.UUID
+	ChildRoleID  *uuid.UUID
+}
+
+// RoleImpact descreve as permissões efetivas que uma função perderia
+type RoleImpact struct {
+	RoleID          uuid.UUID `json:"roleId"`
+	RoleCode        string    `json:"roleCode"`
+	RoleName        string    `json:"roleName"`
+	IsTarget        bool      `json:"isTarget"`
+	LostPermissions []string  `json:"lostPermissions"`
+}
+
+// UserImpact descreve as permissões e escopos que um usuário perderia
+type UserImpact struct {
+	UserID          uuid.UUID `json:"userId"`
+	ViaRoles        []string  `json:"viaRoles"`
+	LostPermissions []string  `json:"lostPermissions"`
+	LostScopes      []string  `json:"lostScopes"`
+}
+
+// ScopeImpact descreve um escopo de API que deixaria de funcionar para usuários
+type ScopeImpact struct {
+	Scope         string   `json:"scope"`
+	Permissions   []string `json:"permissions"`
+	AffectedUsers int      `json:"affectedUsers"`
+}
+
+// ImpactSummary contém os totais do relatório de impacto
+type ImpactSummary struct {
+	AffectedRoles        int `json:"affectedRoles"`
+	AffectedUsers        int `json:"affectedUsers"`
+	UsersRetainingAccess int `json:"usersRetainingAccess"`
+	BrokenScopes         int `json:"brokenScopes"`
+	PermissionsRemoved   int `json:"permissionsRemoved"`
+}
+
+// ImpactReport representa o relatório estruturado de impacto de uma alteração proposta
+type ImpactReport struct {
+	TenantID       uuid.UUID     `json:"tenantId"`
+	Mutation       MutationType  `json:"mutation"`
+	TargetRoleID   uuid.UUID     `json:"targetRoleId"`
+	TargetRoleCode string        `json:"targetRoleCode"`
+	PermissionID   *uuid.UUID    `json:"permissionId,omitempty"`
+	ChildRoleID    *uuid.UUID    `json:"childRoleId,omitempty"`
+	AffectedRoles  []RoleImpact  `json:"affectedRoles"`
+	AffectedUsers  []UserImpact  `json:"affectedUsers"`
+	BrokenScopes   []ScopeImpact `json:"brokenScopes"`
+	Summary        ImpactSummary `json:"summary"`
+	AnalyzedAt     time.Time     `json:"analyzedAt"`
+}
+
+// ImpactAnalysisService define a interface de serviço para análise de impacto de alterações de acesso
+type ImpactAnalysisService interface {
+	// AnalyzeImpact calcula o impacto de uma alteração proposta sem aplicá-la
+	AnalyzeImpact(ctx context.Context, req ImpactAnalysisRequest) (*ImpactReport, error)
+}
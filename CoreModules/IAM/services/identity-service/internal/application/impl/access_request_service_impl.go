@@ -0,0 +1,444 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão do prazo de decisão dos pedidos de acesso
+const (
+	DefaultAccessRequestSLA       = 48 * time.Hour
+	DefaultAccessRequestSLAWindow = 30 * 24 * time.Hour
+)
+
+// AccessRequestServiceImpl implementa a interface AccessRequestService
+type AccessRequestServiceImpl struct {
+	repository  repository.AccessRequestRepository
+	roleService application.RoleService
+	sla         time.Duration
+	slaWindow   time.Duration
+	now         func() time.Time
+}
+
+// NewAccessRequestService cria uma nova instância de AccessRequestService
+// As funções são concedidas através do RoleService; as permissões diretamente no repositório
+// Um prazo não positivo usa DefaultAccessRequestSLA
+func NewAccessRequestService(
+	repo repository.AccessRequestRepository,
+	roleService application.RoleService,
+	sla time.Duration,
+) application.AccessRequestService {
+	if sla <= 0 {
+		sla = DefaultAccessRequestSLA
+	}
+	return &AccessRequestServiceImpl{
+		repository:  repo,
+		roleService: roleService,
+		sla:         sla,
+		slaWindow:   DefaultAccessRequestSLAWindow,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Submit regista um pedido pendente
+func (s *AccessRequestServiceImpl) Submit(ctx context.Context, req *application.SubmitAccessRequest) (*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.Submit", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("requester_id", req.RequesterID.String()),
+		attribute.String("target_type", string(req.TargetType)),
+		attribute.String("target_id", req.TargetID.String()),
+	))
+	defer span.End()
+
+	accessRequest, err := model.NewAccessRequest(
+		req.TenantID, req.RequesterID, req.TargetType, req.TargetID, req.Justification, req.ExpiresAt, s.now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTarget(ctx, accessRequest); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, accessRequest); err != nil {
+		if errors.Is(err, model.ErrDuplicateAccessRequest) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar pedido de acesso: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", accessRequest.TenantID.String()).
+		Str("request_id", accessRequest.ID.String()).
+		Str("requester_id", accessRequest.RequesterID.String()).
+		Str("target_type", string(accessRequest.TargetType)).
+		Str("target_id", accessRequest.TargetID.String()).
+		Msg("Pedido de acesso registado")
+
+	return accessRequest, nil
+}
+
+// Get recupera um pedido pelo ID
+func (s *AccessRequestServiceImpl) Get(ctx context.Context, tenantID, requestID uuid.UUID) (*model.AccessRequest, error) {
+	accessRequest, err := s.repository.Get(ctx, tenantID, requestID)
+	if err != nil {
+		if errors.Is(err, model.ErrAccessRequestNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter pedido de acesso: %w", err)
+	}
+	return accessRequest, nil
+}
+
+// ListByRequester recupera os pedidos feitos pelo usuário
+func (s *AccessRequestServiceImpl) ListByRequester(ctx context.Context, tenantID, requesterID uuid.UUID) ([]*model.AccessRequest, error) {
+	requests, err := s.repository.List(ctx, tenantID, model.AccessRequestFilter{RequesterID: &requesterID})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar pedidos de acesso: %w", err)
+	}
+	return requests, nil
+}
+
+// ListPendingForApprover recupera os pedidos pendentes que o aprovador pode decidir,
+// excluindo os do próprio aprovador
+func (s *AccessRequestServiceImpl) ListPendingForApprover(ctx context.Context, tenantID, approverID uuid.UUID) ([]*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.ListPendingForApprover", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("approver_id", approverID.String()),
+	))
+	defer span.End()
+
+	approvers, err := s.repository.ListApprovers(ctx, tenantID, &approverID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter aprovadores: %w", err)
+	}
+	if len(approvers) == 0 {
+		return []*model.AccessRequest{}, nil
+	}
+
+	filter := model.AccessRequestFilter{Status: model.AccessRequestStatusPending, RolesOnly: true}
+	for _, approver := range approvers {
+		if approver.Kind == model.AccessApproverKindDelegatedAdmin {
+			filter.RolesOnly = false
+			filter.RoleIDs = nil
+			break
+		}
+		filter.RoleIDs = append(filter.RoleIDs, *approver.RoleID)
+	}
+
+	requests, err := s.repository.List(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar pedidos de acesso pendentes: %w", err)
+	}
+
+	pending := make([]*model.AccessRequest, 0, len(requests))
+	for _, req := range requests {
+		if req.RequesterID != approverID {
+			pending = append(pending, req)
+		}
+	}
+	return pending, nil
+}
+
+// Approve aprova o pedido e concede o acesso ao requerente
+// O pedido é marcado como aprovado antes da concessão, para que decisões concorrentes falhem;
+// se a concessão falhar o pedido volta a ficar pendente
+func (s *AccessRequestServiceImpl) Approve(ctx context.Context, decision *application.AccessRequestDecision) (*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.Approve", trace.WithAttributes(
+		attribute.String("tenant_id", decision.TenantID.String()),
+		attribute.String("request_id", decision.RequestID.String()),
+		attribute.String("approver_id", decision.ApproverID.String()),
+	))
+	defer span.End()
+
+	accessRequest, err := s.authorizedRequest(ctx, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := *accessRequest
+	if err := accessRequest.Approve(decision.ApproverID, decision.Reason, decision.ExpiresAt, s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveDecision(ctx, accessRequest, model.AccessRequestStatusPending); err != nil {
+		return nil, err
+	}
+
+	if err := s.grant(ctx, accessRequest); err != nil {
+		if revertErr := s.repository.SaveDecision(ctx, &pending, model.AccessRequestStatusApproved); revertErr != nil {
+			log.Error().Err(revertErr).
+				Str("tenant_id", accessRequest.TenantID.String()).
+				Str("request_id", accessRequest.ID.String()).
+				Msg("Erro ao repor pedido de acesso pendente após falha na concessão")
+		}
+		return nil, fmt.Errorf("erro ao conceder acesso aprovado: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", accessRequest.TenantID.String()).
+		Str("request_id", accessRequest.ID.String()).
+		Str("approver_id", decision.ApproverID.String()).
+		Dur("pending_for", accessRequest.PendingFor(s.now())).
+		Msg("Pedido de acesso aprovado")
+
+	return accessRequest, nil
+}
+
+// Reject rejeita o pedido
+func (s *AccessRequestServiceImpl) Reject(ctx context.Context, decision *application.AccessRequestDecision) (*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.Reject", trace.WithAttributes(
+		attribute.String("tenant_id", decision.TenantID.String()),
+		attribute.String("request_id", decision.RequestID.String()),
+		attribute.String("approver_id", decision.ApproverID.String()),
+	))
+	defer span.End()
+
+	accessRequest, err := s.authorizedRequest(ctx, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := accessRequest.Reject(decision.ApproverID, decision.Reason, s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveDecision(ctx, accessRequest, model.AccessRequestStatusPending); err != nil {
+		return nil, err
+	}
+	return accessRequest, nil
+}
+
+// Cancel retira um pedido pendente a pedido do requerente
+func (s *AccessRequestServiceImpl) Cancel(ctx context.Context, tenantID, requestID, requesterID uuid.UUID) (*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.Cancel", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("request_id", requestID.String()),
+	))
+	defer span.End()
+
+	accessRequest, err := s.Get(ctx, tenantID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := accessRequest.Cancel(requesterID, s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveDecision(ctx, accessRequest, model.AccessRequestStatusPending); err != nil {
+		return nil, err
+	}
+	return accessRequest, nil
+}
+
+// AddApprover atribui a um usuário a autoridade para decidir pedidos
+func (s *AccessRequestServiceImpl) AddApprover(ctx context.Context, approver *model.AccessRequestApprover) (*model.AccessRequestApprover, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.AddApprover", trace.WithAttributes(
+		attribute.String("tenant_id", approver.TenantID.String()),
+		attribute.String("user_id", approver.UserID.String()),
+		attribute.String("kind", string(approver.Kind)),
+	))
+	defer span.End()
+
+	if err := approver.Validate(); err != nil {
+		return nil, err
+	}
+
+	if approver.RoleID != nil {
+		if _, err := s.roleService.GetRole(ctx, approver.TenantID, *approver.RoleID); err != nil {
+			return nil, err
+		}
+	}
+
+	approver.ID = uuid.New()
+	approver.CreatedAt = s.now()
+	if err := s.repository.SaveApprover(ctx, approver); err != nil {
+		return nil, fmt.Errorf("erro ao gravar aprovador: %w", err)
+	}
+	return approver, nil
+}
+
+// RemoveApprover retira a autoridade de um aprovador
+func (s *AccessRequestServiceImpl) RemoveApprover(ctx context.Context, tenantID, approverID uuid.UUID) error {
+	if err := s.repository.DeleteApprover(ctx, tenantID, approverID); err != nil {
+		if errors.Is(err, model.ErrAccessApproverNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao remover aprovador: %w", err)
+	}
+	return nil
+}
+
+// ListApprovers recupera os aprovadores do tenant
+func (s *AccessRequestServiceImpl) ListApprovers(ctx context.Context, tenantID uuid.UUID) ([]*model.AccessRequestApprover, error) {
+	approvers, err := s.repository.ListApprovers(ctx, tenantID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar aprovadores: %w", err)
+	}
+	return approvers, nil
+}
+
+// GetSLAMetrics calcula as métricas de prazo dos pedidos pendentes e dos decididos na janela
+// Os pedidos cancelados pelo requerente não contam como decididos
+func (s *AccessRequestServiceImpl) GetSLAMetrics(ctx context.Context, tenantID uuid.UUID) (*application.AccessRequestSLAMetrics, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestServiceImpl.GetSLAMetrics", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	now := s.now()
+	metrics := &application.AccessRequestSLAMetrics{
+		TenantID:    tenantID,
+		SLASeconds:  int64(s.sla / time.Second),
+		WindowStart: now.Add(-s.slaWindow),
+		GeneratedAt: now,
+	}
+
+	pending, err := s.repository.List(ctx, tenantID, model.AccessRequestFilter{Status: model.AccessRequestStatusPending})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar pedidos de acesso pendentes: %w", err)
+	}
+	var oldest time.Duration
+	for _, req := range pending {
+		age := req.PendingFor(now)
+		metrics.PendingCount++
+		if age > s.sla {
+			metrics.PendingOverSLA++
+		}
+		if age > oldest {
+			oldest = age
+		}
+	}
+	metrics.OldestPendingSeconds = int64(oldest / time.Second)
+
+	decided, err := s.repository.List(ctx, tenantID, model.AccessRequestFilter{DecidedSince: &metrics.WindowStart})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar pedidos de acesso decididos: %w", err)
+	}
+	var total, longest time.Duration
+	for _, req := range decided {
+		if req.Status != model.AccessRequestStatusApproved && req.Status != model.AccessRequestStatusRejected {
+			continue
+		}
+		elapsed := req.PendingFor(now)
+		metrics.DecidedCount++
+		total += elapsed
+		if elapsed <= s.sla {
+			metrics.DecidedWithinSLA++
+		}
+		if elapsed > longest {
+			longest = elapsed
+		}
+	}
+	metrics.MaxDecisionTimeSeconds = int64(longest / time.Second)
+	if metrics.DecidedCount > 0 {
+		metrics.AverageDecisionTimeSeconds = int64(total / time.Duration(metrics.DecidedCount) / time.Second)
+	}
+
+	span.SetAttributes(
+		attribute.Int("pending_count", metrics.PendingCount),
+		attribute.Int("pending_over_sla", metrics.PendingOverSLA),
+		attribute.Int("decided_count", metrics.DecidedCount),
+	)
+
+	return metrics, nil
+}
+
+// validateTarget verifica que o destino existe e que o requerente ainda não tem o acesso
+func (s *AccessRequestServiceImpl) validateTarget(ctx context.Context, req *model.AccessRequest) error {
+	switch req.TargetType {
+	case model.AccessRequestTargetRole:
+		if _, err := s.roleService.GetRole(ctx, req.TenantID, req.TargetID); err != nil {
+			return err
+		}
+
+		assignments, err := s.roleService.GetUserActiveRoles(ctx, req.TenantID, req.RequesterID)
+		if err != nil {
+			return fmt.Errorf("erro ao obter funções do requerente: %w", err)
+		}
+		for _, assignment := range assignments {
+			if assignment.Role != nil && assignment.Role.ID == req.TargetID {
+				return model.ErrAccessAlreadyGranted
+			}
+		}
+	case model.AccessRequestTargetPermission:
+		exists, err := s.repository.PermissionExists(ctx, req.TenantID, req.TargetID)
+		if err != nil {
+			return fmt.Errorf("erro ao verificar permissão: %w", err)
+		}
+		if !exists {
+			return application.ErrPermissionNotFound
+		}
+	}
+	return nil
+}
+
+// authorizedRequest recupera o pedido e verifica que o aprovador o pode decidir
+func (s *AccessRequestServiceImpl) authorizedRequest(ctx context.Context, decision *application.AccessRequestDecision) (*model.AccessRequest, error) {
+	accessRequest, err := s.Get(ctx, decision.TenantID, decision.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if !accessRequest.IsPending() {
+		return nil, fmt.Errorf("%w: estado atual %s", model.ErrAccessRequestNotPending, accessRequest.Status)
+	}
+	if accessRequest.RequesterID == decision.ApproverID {
+		return nil, model.ErrAccessRequestSelfApproval
+	}
+
+	approvers, err := s.repository.ListApprovers(ctx, decision.TenantID, &decision.ApproverID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter aprovadores: %w", err)
+	}
+	for _, approver := range approvers {
+		if approver.Covers(accessRequest) {
+			return accessRequest, nil
+		}
+	}
+	return nil, model.ErrAccessRequestApproverNotAuthorized
+}
+
+// grant concede ao requerente o acesso aprovado
+func (s *AccessRequestServiceImpl) grant(ctx context.Context, req *model.AccessRequest) error {
+	switch req.TargetType {
+	case model.AccessRequestTargetRole:
+		err := s.roleService.AssignUserToRole(ctx, req.TenantID, req.TargetID, req.RequesterID, *req.DecidedAt, req.GrantExpiresAt, *req.DecidedBy)
+		// A função pode ter sido atribuída por outra via enquanto o pedido aguardava
+		if errors.Is(err, application.ErrUserAlreadyAssigned) {
+			log.Warn().
+				Str("tenant_id", req.TenantID.String()).
+				Str("request_id", req.ID.String()).
+				Msg("Função do pedido de acesso já atribuída ao requerente")
+			return nil
+		}
+		return err
+	case model.AccessRequestTargetPermission:
+		return s.repository.GrantPermission(ctx, req.TenantID, req.RequesterID, req.TargetID, req.GrantExpiresAt, *req.DecidedBy, req.ID)
+	default:
+		return fmt.Errorf("%w: tipo de destino desconhecido %q", model.ErrInvalidAccessRequest, req.TargetType)
+	}
+}
+
+// saveDecision grava a decisão, preservando o erro de conflito
+func (s *AccessRequestServiceImpl) saveDecision(ctx context.Context, req *model.AccessRequest, expected model.AccessRequestStatus) error {
+	if err := s.repository.SaveDecision(ctx, req, expected); err != nil {
+		if errors.Is(err, model.ErrAccessRequestConflict) {
+			return err
+		}
+		return fmt.Errorf("erro ao gravar decisão do pedido de acesso: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da ligação de contas
+const (
+	DefaultAccountLinkCandidateTTL = 15 * time.Minute
+	DefaultAccountLinkReauthMaxAge = 5 * time.Minute
+	DefaultAccountLinkEventsLimit  = 100
+	MaxAccountLinkEventsLimit      = 1000
+	// Diferença de relógio tolerada num instante de autenticação no futuro
+	accountLinkClockSkew = time.Minute
+)
+
+// AccountLinkConfig configura a ligação de contas
+type AccountLinkConfig struct {
+	// Tempo durante o qual um candidato pode ser ligado depois do login federado que o originou
+	CandidateTTL time.Duration
+	// Idade máxima da autenticação do usuário para ligar ou remover identidades
+	ReauthMaxAge time.Duration
+}
+
+// DefaultAccountLinkConfig retorna a configuração padrão da ligação de contas
+func DefaultAccountLinkConfig() AccountLinkConfig {
+	return AccountLinkConfig{
+		CandidateTTL: DefaultAccountLinkCandidateTTL,
+		ReauthMaxAge: DefaultAccountLinkReauthMaxAge,
+	}
+}
+
+// AccountLinkServiceImpl implementa a interface AccountLinkService
+type AccountLinkServiceImpl struct {
+	repository  repository.AccountLinkRepository
+	roleService application.RoleService
+	config      AccountLinkConfig
+	now         func() time.Time
+}
+
+// NewAccountLinkService cria uma nova instância de AccountLinkService
+// As funções atribuídas pela federação são retiradas através do RoleService quando a identidade
+// é removida. Os valores não positivos da configuração usam os padrões
+func NewAccountLinkService(repo repository.AccountLinkRepository, roleService application.RoleService, config AccountLinkConfig) application.AccountLinkService {
+	defaults := DefaultAccountLinkConfig()
+	if config.CandidateTTL <= 0 {
+		config.CandidateTTL = defaults.CandidateTTL
+	}
+	if config.ReauthMaxAge <= 0 {
+		config.ReauthMaxAge = defaults.ReauthMaxAge
+	}
+
+	return &AccountLinkServiceImpl{
+		repository:  repo,
+		roleService: roleService,
+		config:      config,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// DetectCandidate regista um candidato à ligação quando o email corresponde a uma conta local
+// ativa com email verificado. Não são propostos candidatos a usuários que já tenham outro
+// NameID do mesmo fornecedor
+func (s *AccountLinkServiceImpl) DetectCandidate(ctx context.Context, req *application.DetectAccountLinkRequest) (*model.AccountLinkCandidate, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.DetectCandidate", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("provider_id", req.ProviderID.String()),
+	))
+	defer span.End()
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || strings.TrimSpace(req.Subject) == "" {
+		return nil, nil
+	}
+
+	user, err := s.repository.FindUserByVerifiedEmail(ctx, req.TenantID, email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao procurar conta local para ligação: %w", err)
+	}
+	if !user.CanAuthenticate() {
+		return nil, nil
+	}
+
+	identities, err := s.repository.ListFederatedIdentities(ctx, req.TenantID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar identidades federadas do usuário: %w", err)
+	}
+	for _, identity := range identities {
+		if identity.ProviderID == req.ProviderID {
+			log.Warn().
+				Str("tenant_id", req.TenantID.String()).
+				Str("provider_id", req.ProviderID.String()).
+				Str("user_id", user.ID.String()).
+				Msg("Login federado com novo NameID para usuário já ligado ao fornecedor; ligação não proposta")
+			return nil, nil
+		}
+	}
+
+	now := s.now()
+	candidate := &model.AccountLinkCandidate{
+		ID:         uuid.New(),
+		TenantID:   req.TenantID,
+		UserID:     user.ID,
+		ProviderID: req.ProviderID,
+		Subject:    req.Subject,
+		Email:      email,
+		Status:     model.AccountLinkCandidatePending,
+		DetectedAt: now,
+		ExpiresAt:  now.Add(s.config.CandidateTTL),
+	}
+	event := s.newEvent(candidate, model.AccountLinkActionCandidateDetected, nil, nil, req.IPAddress)
+
+	if err := s.repository.SaveCandidate(ctx, candidate, event); err != nil {
+		return nil, fmt.Errorf("erro ao gravar candidato à ligação de contas: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", candidate.TenantID.String()).
+		Str("provider_id", candidate.ProviderID.String()).
+		Str("user_id", candidate.UserID.String()).
+		Str("candidate_id", candidate.ID.String()).
+		Msg("Candidato à ligação de contas detetado no login federado")
+	return candidate, nil
+}
+
+// ListLinkedIdentities recupera os métodos de login do usuário
+func (s *AccountLinkServiceImpl) ListLinkedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LinkedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.ListLinkedIdentities", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	identities, err := s.repository.ListLinkedIdentities(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar identidades ligadas: %w", err)
+	}
+	if identities == nil {
+		identities = []*model.LinkedIdentity{}
+	}
+	return identities, nil
+}
+
+// ListCandidates recupera os candidatos à ligação pendentes do usuário
+func (s *AccountLinkServiceImpl) ListCandidates(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.AccountLinkCandidate, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.ListCandidates", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	candidates, err := s.repository.ListOpenCandidates(ctx, tenantID, userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar candidatos à ligação de contas: %w", err)
+	}
+	if candidates == nil {
+		candidates = []*model.AccountLinkCandidate{}
+	}
+	return candidates, nil
+}
+
+// Link liga o candidato à conta do usuário, que tem de se ter autenticado recentemente
+// O login federado que originou o candidato prova o controlo da identidade federada e a
+// autenticação recente prova o controlo da conta local. As funções mapeadas pelo fornecedor
+// são sincronizadas no login federado seguinte
+func (s *AccountLinkServiceImpl) Link(ctx context.Context, req *application.LinkAccountRequest) (*model.LinkedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.Link", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("candidate_id", req.CandidateID.String()),
+	))
+	defer span.End()
+
+	now := s.now()
+	if err := s.requireRecentAuthentication(req.AuthenticatedAt, now); err != nil {
+		return nil, err
+	}
+
+	candidate, err := s.ownCandidate(ctx, req.TenantID, req.UserID, req.CandidateID)
+	if err != nil {
+		return nil, err
+	}
+	if !candidate.IsOpen(now) {
+		return nil, model.ErrAccountLinkCandidateClosed
+	}
+
+	identity := &model.FederatedIdentity{
+		ID:             uuid.New(),
+		TenantID:       candidate.TenantID,
+		ProviderID:     candidate.ProviderID,
+		Subject:        candidate.Subject,
+		UserID:         candidate.UserID,
+		GrantedRoleIDs: []uuid.UUID{},
+		CreatedAt:      now,
+	}
+	candidate.Status = model.AccountLinkCandidateLinked
+	candidate.ResolvedAt = &now
+
+	authenticatedAt := req.AuthenticatedAt.UTC()
+	event := s.newEvent(candidate, model.AccountLinkActionLinked, &req.UserID, &authenticatedAt, req.IPAddress)
+	event.IdentityID = &identity.ID
+
+	if err := s.repository.LinkCandidate(ctx, candidate, identity, event); err != nil {
+		if errors.Is(err, model.ErrAccountLinkCandidateClosed) || errors.Is(err, model.ErrIdentityAlreadyLinked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao ligar identidade federada: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", identity.TenantID.String()).
+		Str("provider_id", identity.ProviderID.String()).
+		Str("user_id", identity.UserID.String()).
+		Str("identity_id", identity.ID.String()).
+		Msg("Identidade federada ligada à conta local")
+
+	providerID := identity.ProviderID
+	return &model.LinkedIdentity{
+		ID:         identity.ID,
+		Kind:       model.LinkedIdentitySAML,
+		ProviderID: &providerID,
+		Subject:    identity.Subject,
+		LinkedAt:   identity.CreatedAt,
+	}, nil
+}
+
+// DismissCandidate recusa um candidato à ligação do usuário
+func (s *AccountLinkServiceImpl) DismissCandidate(ctx context.Context, tenantID, userID, candidateID uuid.UUID, ipAddress string) (*model.AccountLinkCandidate, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.DismissCandidate", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+		attribute.String("candidate_id", candidateID.String()),
+	))
+	defer span.End()
+
+	candidate, err := s.ownCandidate(ctx, tenantID, userID, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if !candidate.IsOpen(now) {
+		return nil, model.ErrAccountLinkCandidateClosed
+	}
+
+	candidate.Status = model.AccountLinkCandidateDismissed
+	candidate.ResolvedAt = &now
+	event := s.newEvent(candidate, model.AccountLinkActionCandidateDismissed, &userID, nil, ipAddress)
+
+	if err := s.repository.DismissCandidate(ctx, candidate, event); err != nil {
+		if errors.Is(err, model.ErrAccountLinkCandidateClosed) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao recusar candidato à ligação de contas: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("user_id", userID.String()).
+		Str("candidate_id", candidateID.String()).
+		Msg("Candidato à ligação de contas recusado")
+	return candidate, nil
+}
+
+// Unlink remove uma identidade federada da conta do usuário, que tem de se ter autenticado
+// recentemente e manter pelo menos outro método de login
+// As funções atribuídas pela federação são retiradas; as falhas individuais são registadas
+func (s *AccountLinkServiceImpl) Unlink(ctx context.Context, req *application.UnlinkAccountRequest) error {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.Unlink", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("identity_id", req.IdentityID.String()),
+	))
+	defer span.End()
+
+	now := s.now()
+	if err := s.requireRecentAuthentication(req.AuthenticatedAt, now); err != nil {
+		return err
+	}
+
+	authenticatedAt := req.AuthenticatedAt.UTC()
+	event := &model.AccountLinkEvent{
+		ID:              uuid.New(),
+		TenantID:        req.TenantID,
+		UserID:          req.UserID,
+		ActorID:         &req.UserID,
+		Action:          model.AccountLinkActionUnlinked,
+		IdentityID:      &req.IdentityID,
+		AuthenticatedAt: &authenticatedAt,
+		IPAddress:       req.IPAddress,
+		OccurredAt:      now,
+	}
+
+	identity, err := s.repository.UnlinkIdentity(ctx, req.TenantID, req.UserID, req.IdentityID, event)
+	if err != nil {
+		if errors.Is(err, model.ErrLinkedIdentityNotFound) || errors.Is(err, model.ErrLastLinkedIdentity) {
+			return err
+		}
+		return fmt.Errorf("erro ao remover identidade federada: %w", err)
+	}
+
+	for _, roleID := range identity.GrantedRoleIDs {
+		err := s.roleService.RevokeUserFromRole(ctx, identity.TenantID, roleID, identity.UserID, req.UserID)
+		if err != nil && !errors.Is(err, application.ErrUserNotAssigned) && !errors.Is(err, application.ErrRoleNotFound) {
+			log.Error().Err(err).
+				Str("tenant_id", identity.TenantID.String()).
+				Str("user_id", identity.UserID.String()).
+				Str("role_id", roleID.String()).
+				Msg("Falha ao retirar função atribuída pela identidade federada removida")
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", identity.TenantID.String()).
+		Str("provider_id", identity.ProviderID.String()).
+		Str("user_id", identity.UserID.String()).
+		Str("identity_id", identity.ID.String()).
+		Int("roles_revoked", len(identity.GrantedRoleIDs)).
+		Msg("Identidade federada removida da conta")
+	return nil
+}
+
+// ListEvents recupera o registo de auditoria da ligação de contas do tenant
+func (s *AccountLinkServiceImpl) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.AccountLinkEventFilter) ([]*model.AccountLinkEvent, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkServiceImpl.ListEvents", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	if filter.Action != "" && !filter.Action.IsValid() {
+		return nil, fmt.Errorf("%w: operação %q desconhecida", model.ErrInvalidAccountLinkFilter, filter.Action)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAccountLinkEventsLimit
+	}
+	if filter.Limit > MaxAccountLinkEventsLimit {
+		filter.Limit = MaxAccountLinkEventsLimit
+	}
+
+	events, err := s.repository.ListEvents(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar registo de ligação de contas: %w", err)
+	}
+	if events == nil {
+		events = []*model.AccountLinkEvent{}
+	}
+	return events, nil
+}
+
+// requireRecentAuthentication verifica que a autenticação do usuário está dentro da janela configurada
+func (s *AccountLinkServiceImpl) requireRecentAuthentication(authenticatedAt, now time.Time) error {
+	if authenticatedAt.IsZero() {
+		return model.ErrReauthenticationRequired
+	}
+	age := now.Sub(authenticatedAt)
+	if age > s.config.ReauthMaxAge || age < -accountLinkClockSkew {
+		return model.ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// ownCandidate recupera um candidato do usuário; os candidatos de outros usuários não são revelados
+func (s *AccountLinkServiceImpl) ownCandidate(ctx context.Context, tenantID, userID, candidateID uuid.UUID) (*model.AccountLinkCandidate, error) {
+	candidate, err := s.repository.GetCandidate(ctx, tenantID, candidateID)
+	if errors.Is(err, model.ErrAccountLinkCandidateNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter candidato à ligação de contas: %w", err)
+	}
+	if candidate.UserID != userID {
+		return nil, model.ErrAccountLinkCandidateNotFound
+	}
+	return candidate, nil
+}
+
+// newEvent cria o registo de auditoria de uma operação sobre um candidato
+func (s *AccountLinkServiceImpl) newEvent(candidate *model.AccountLinkCandidate, action model.AccountLinkAction, actorID *uuid.UUID, authenticatedAt *time.Time, ipAddress string) *model.AccountLinkEvent {
+	candidateID := candidate.ID
+	return &model.AccountLinkEvent{
+		ID:              uuid.New(),
+		TenantID:        candidate.TenantID,
+		UserID:          candidate.UserID,
+		ActorID:         actorID,
+		Action:          action,
+		ProviderID:      candidate.ProviderID,
+		Subject:         candidate.Subject,
+		CandidateID:     &candidateID,
+		AuthenticatedAt: authenticatedAt,
+		IPAddress:       ipAddress,
+		OccurredAt:      s.now(),
+	}
+}
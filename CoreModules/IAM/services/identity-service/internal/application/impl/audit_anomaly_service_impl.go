@@ -0,0 +1,558 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da deteção de anomalias no fluxo de auditoria
+const (
+	DefaultBusinessHoursStart        = 8
+	DefaultBusinessHoursEnd          = 19
+	DefaultMassRoleDeletionCount     = 5
+	DefaultMassRoleDeletionWindow    = 10 * time.Minute
+	DefaultNewAdminObservationWindow = 72 * time.Hour
+)
+
+// AuditAnomalyConfig configura as regras de deteção de anomalias
+type AuditAnomalyConfig struct {
+	// Horário de expediente [BusinessHoursStart, BusinessHoursEnd) nos dias úteis, no fuso indicado
+	BusinessHoursStart int
+	BusinessHoursEnd   int
+	BusinessDays       []time.Weekday
+	Location           *time.Location
+	// Códigos das funções administrativas; a atribuição destas funções é tratada como privilegiada
+	PrivilegedRoleCodes []string
+	// Número de exclusões de funções pelo mesmo ator, dentro da janela, que constitui uma exclusão em massa
+	MassRoleDeletionCount  int
+	MassRoleDeletionWindow time.Duration
+	// Período após a promoção em que um administrador é considerado recente
+	NewAdminWindow time.Duration
+}
+
+// DefaultAuditAnomalyConfig retorna a configuração padrão da deteção de anomalias
+func DefaultAuditAnomalyConfig() AuditAnomalyConfig {
+	return AuditAnomalyConfig{
+		BusinessHoursStart:     DefaultBusinessHoursStart,
+		BusinessHoursEnd:       DefaultBusinessHoursEnd,
+		BusinessDays:           []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Location:               time.UTC,
+		PrivilegedRoleCodes:    []string{"ADMIN", "TENANT_ADMIN", "SUPER_ADMIN", "SECURITY_ADMIN"},
+		MassRoleDeletionCount:  DefaultMassRoleDeletionCount,
+		MassRoleDeletionWindow: DefaultMassRoleDeletionWindow,
+		NewAdminWindow:         DefaultNewAdminObservationWindow,
+	}
+}
+
+// Ordem das severidades, usada para escalar incidentes de exclusão em massa
+var securityIncidentSeverityRank = map[model.SecurityIncidentSeverity]int{
+	model.SecurityIncidentSeverityLow:      1,
+	model.SecurityIncidentSeverityMedium:   2,
+	model.SecurityIncidentSeverityHigh:     3,
+	model.SecurityIncidentSeverityCritical: 4,
+}
+
+// anomalyActorKey identifica um ator dentro de um tenant
+type anomalyActorKey struct {
+	tenantID uuid.UUID
+	actorID  uuid.UUID
+}
+
+// massDeletionState acompanha as exclusões recentes de um ator
+type massDeletionState struct {
+	deletions       []model.SecurityIncidentEvidence
+	flaggedSeverity model.SecurityIncidentSeverity
+	flaggedAt       time.Time
+}
+
+// AuditAnomalyServiceImpl implementa a interface AuditAnomalyService
+// O estado das janelas de observação é mantido em memória por instância
+type AuditAnomalyServiceImpl struct {
+	repository   repository.SecurityIncidentRepository
+	publisher    event.Publisher
+	config       AuditAnomalyConfig
+	privileged   map[string]struct{}
+	businessDays map[time.Weekday]struct{}
+
+	mutex      sync.Mutex
+	deletions  map[anomalyActorKey]*massDeletionState
+	adminSince map[anomalyActorKey]time.Time
+
+	now func() time.Time
+}
+
+// NewAuditAnomalyService cria uma nova instância de AuditAnomalyService
+// Os incidentes abertos são publicados como eventos de segurança no publicador indicado, que pode ser nulo
+func NewAuditAnomalyService(
+	repo repository.SecurityIncidentRepository,
+	publisher event.Publisher,
+	config AuditAnomalyConfig,
+) application.AuditAnomalyService {
+	defaults := DefaultAuditAnomalyConfig()
+	if config.BusinessHoursEnd <= config.BusinessHoursStart {
+		config.BusinessHoursStart, config.BusinessHoursEnd = defaults.BusinessHoursStart, defaults.BusinessHoursEnd
+	}
+	if len(config.BusinessDays) == 0 {
+		config.BusinessDays = defaults.BusinessDays
+	}
+	if config.Location == nil {
+		config.Location = defaults.Location
+	}
+	if config.MassRoleDeletionCount <= 0 {
+		config.MassRoleDeletionCount = defaults.MassRoleDeletionCount
+	}
+	if config.MassRoleDeletionWindow <= 0 {
+		config.MassRoleDeletionWindow = defaults.MassRoleDeletionWindow
+	}
+	if config.NewAdminWindow <= 0 {
+		config.NewAdminWindow = defaults.NewAdminWindow
+	}
+
+	privileged := make(map[string]struct{}, len(config.PrivilegedRoleCodes))
+	for _, code := range config.PrivilegedRoleCodes {
+		privileged[strings.ToUpper(strings.TrimSpace(code))] = struct{}{}
+	}
+	businessDays := make(map[time.Weekday]struct{}, len(config.BusinessDays))
+	for _, day := range config.BusinessDays {
+		businessDays[day] = struct{}{}
+	}
+
+	return &AuditAnomalyServiceImpl{
+		repository:   repo,
+		publisher:    publisher,
+		config:       config,
+		privileged:   privileged,
+		businessDays: businessDays,
+		deletions:    make(map[anomalyActorKey]*massDeletionState),
+		adminSince:   make(map[anomalyActorKey]time.Time),
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// anomalyCandidate descreve um incidente a abrir
+type anomalyCandidate struct {
+	tenantID uuid.UUID
+	rule     model.SecurityIncidentRule
+	severity model.SecurityIncidentSeverity
+	actorID  *uuid.UUID
+	summary  string
+	evidence []model.SecurityIncidentEvidence
+}
+
+// Analyze avalia um evento de auditoria e abre incidentes para as anomalias detetadas
+func (s *AuditAnomalyServiceImpl) Analyze(ctx context.Context, evt event.Event) ([]*model.SecurityIncident, error) {
+	ctx, span := tracer.Start(ctx, "AuditAnomalyServiceImpl.Analyze", trace.WithAttributes(
+		attribute.String("event_type", evt.GetType()),
+	))
+	defer span.End()
+
+	occurredAt := evt.GetTime()
+	if occurredAt.IsZero() {
+		occurredAt = s.now()
+	}
+
+	var candidates []anomalyCandidate
+	switch e := evt.(type) {
+	case *event.RoleAssignedToUsersEvent:
+		candidates = s.analyzeRoleAssignment(e, occurredAt)
+	case *event.PermissionsAssignedToRoleEvent:
+		candidates = s.analyzePermissionAssignment(e, occurredAt)
+	case *event.RoleSoftDeletedEvent:
+		candidates = s.analyzeRoleDeletion(e.TenantID, e.DeletedBy, e.RoleID, e.Code, e.GetType(), occurredAt)
+	case *event.RoleHardDeletedEvent:
+		candidates = s.analyzeRoleDeletion(e.TenantID, e.DeletedBy, e.RoleID, e.Code, e.GetType(), occurredAt)
+	default:
+		return nil, nil
+	}
+
+	var (
+		incidents []*model.SecurityIncident
+		errs      []error
+	)
+	for _, candidate := range candidates {
+		incident, err := s.openIncident(ctx, candidate)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, errors.Join(errs...)
+}
+
+// analyzeRoleAssignment avalia a atribuição de uma função a usuários
+// A atribuição de funções privilegiadas inicia a janela de observação dos novos administradores
+func (s *AuditAnomalyServiceImpl) analyzeRoleAssignment(e *event.RoleAssignedToUsersEvent, occurredAt time.Time) []anomalyCandidate {
+	privileged := s.isPrivileged(e.RoleCode)
+	roleID := e.RoleID
+	evidence := []model.SecurityIncidentEvidence{{
+		EventType:  e.GetType(),
+		OccurredAt: occurredAt,
+		RoleID:     &roleID,
+		RoleCode:   e.RoleCode,
+		SubjectIDs: e.UserIDs,
+	}}
+
+	var candidates []anomalyCandidate
+	if !s.isBusinessHours(occurredAt) {
+		severity := model.SecurityIncidentSeverityMedium
+		if privileged {
+			severity = model.SecurityIncidentSeverityHigh
+		}
+		candidates = append(candidates, anomalyCandidate{
+			tenantID: e.TenantID,
+			rule:     model.SecurityIncidentRuleOffHoursPrivilegeGrant,
+			severity: severity,
+			actorID:  e.AssignedBy,
+			summary: fmt.Sprintf("Função %s atribuída a %d usuário(s) fora do horário de expediente",
+				e.RoleCode, len(e.UserIDs)),
+			evidence: evidence,
+		})
+	}
+
+	if s.isNewAdmin(e.TenantID, e.AssignedBy, occurredAt) {
+		severity := model.SecurityIncidentSeverityHigh
+		if privileged {
+			severity = model.SecurityIncidentSeverityCritical
+		}
+		candidates = append(candidates, anomalyCandidate{
+			tenantID: e.TenantID,
+			rule:     model.SecurityIncidentRuleNewAdminPermissionGrant,
+			severity: severity,
+			actorID:  e.AssignedBy,
+			summary: fmt.Sprintf("Administrador promovido há menos de %s atribuiu a função %s a %d usuário(s)",
+				s.config.NewAdminWindow, e.RoleCode, len(e.UserIDs)),
+			evidence: evidence,
+		})
+	}
+
+	if privileged {
+		s.mutex.Lock()
+		for _, userID := range e.UserIDs {
+			key := anomalyActorKey{tenantID: e.TenantID, actorID: userID}
+			if _, ok := s.adminSince[key]; !ok {
+				s.adminSince[key] = occurredAt
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	return candidates
+}
+
+// analyzePermissionAssignment avalia a atribuição de permissões a uma função
+func (s *AuditAnomalyServiceImpl) analyzePermissionAssignment(e *event.PermissionsAssignedToRoleEvent, occurredAt time.Time) []anomalyCandidate {
+	privileged := s.isPrivileged(e.RoleCode)
+	roleID := e.RoleID
+	evidence := []model.SecurityIncidentEvidence{{
+		EventType:  e.GetType(),
+		OccurredAt: occurredAt,
+		RoleID:     &roleID,
+		RoleCode:   e.RoleCode,
+		SubjectIDs: e.PermissionIDs,
+	}}
+
+	var candidates []anomalyCandidate
+	if !s.isBusinessHours(occurredAt) {
+		severity := model.SecurityIncidentSeverityMedium
+		if privileged {
+			severity = model.SecurityIncidentSeverityHigh
+		}
+		candidates = append(candidates, anomalyCandidate{
+			tenantID: e.TenantID,
+			rule:     model.SecurityIncidentRuleOffHoursPrivilegeGrant,
+			severity: severity,
+			actorID:  e.AssignedBy,
+			summary: fmt.Sprintf("%d permissão(ões) atribuída(s) à função %s fora do horário de expediente",
+				len(e.PermissionIDs), e.RoleCode),
+			evidence: evidence,
+		})
+	}
+
+	if s.isNewAdmin(e.TenantID, e.AssignedBy, occurredAt) {
+		severity := model.SecurityIncidentSeverityHigh
+		if privileged {
+			severity = model.SecurityIncidentSeverityCritical
+		}
+		candidates = append(candidates, anomalyCandidate{
+			tenantID: e.TenantID,
+			rule:     model.SecurityIncidentRuleNewAdminPermissionGrant,
+			severity: severity,
+			actorID:  e.AssignedBy,
+			summary: fmt.Sprintf("Administrador promovido há menos de %s atribuiu %d permissão(ões) à função %s",
+				s.config.NewAdminWindow, len(e.PermissionIDs), e.RoleCode),
+			evidence: evidence,
+		})
+	}
+
+	return candidates
+}
+
+// analyzeRoleDeletion acumula as exclusões de funções do ator na janela deslizante
+// O incidente é aberto ao atingir o limiar e escalado a crítico ao atingir o dobro;
+// dentro da janela, cada nível de severidade é assinalado uma única vez
+func (s *AuditAnomalyServiceImpl) analyzeRoleDeletion(
+	tenantID uuid.UUID,
+	deletedBy *uuid.UUID,
+	roleID uuid.UUID,
+	roleCode, eventType string,
+	occurredAt time.Time,
+) []anomalyCandidate {
+	key := anomalyActorKey{tenantID: tenantID}
+	if deletedBy != nil {
+		key.actorID = *deletedBy
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.deletions[key]
+	if !ok {
+		state = &massDeletionState{}
+		s.deletions[key] = state
+	}
+
+	windowStart := occurredAt.Add(-s.config.MassRoleDeletionWindow)
+	recent := state.deletions[:0]
+	for _, deletion := range state.deletions {
+		if deletion.OccurredAt.After(windowStart) {
+			recent = append(recent, deletion)
+		}
+	}
+	deletedRoleID := roleID
+	state.deletions = append(recent, model.SecurityIncidentEvidence{
+		EventType:  eventType,
+		OccurredAt: occurredAt,
+		RoleID:     &deletedRoleID,
+		RoleCode:   roleCode,
+	})
+	if !state.flaggedAt.After(windowStart) {
+		state.flaggedSeverity = ""
+	}
+
+	count := len(state.deletions)
+	var severity model.SecurityIncidentSeverity
+	switch {
+	case count >= 2*s.config.MassRoleDeletionCount:
+		severity = model.SecurityIncidentSeverityCritical
+	case count >= s.config.MassRoleDeletionCount:
+		severity = model.SecurityIncidentSeverityHigh
+	default:
+		return nil
+	}
+	if securityIncidentSeverityRank[severity] <= securityIncidentSeverityRank[state.flaggedSeverity] {
+		return nil
+	}
+	state.flaggedSeverity = severity
+	state.flaggedAt = occurredAt
+
+	evidence := make([]model.SecurityIncidentEvidence, count)
+	copy(evidence, state.deletions)
+	return []anomalyCandidate{{
+		tenantID: tenantID,
+		rule:     model.SecurityIncidentRuleMassRoleDeletion,
+		severity: severity,
+		actorID:  deletedBy,
+		summary: fmt.Sprintf("%d funções excluídas pelo mesmo ator em menos de %s",
+			count, s.config.MassRoleDeletionWindow),
+		evidence: evidence,
+	}}
+}
+
+// isBusinessHours indica se o instante está dentro do horário de expediente configurado
+func (s *AuditAnomalyServiceImpl) isBusinessHours(at time.Time) bool {
+	local := at.In(s.config.Location)
+	if _, ok := s.businessDays[local.Weekday()]; !ok {
+		return false
+	}
+	return local.Hour() >= s.config.BusinessHoursStart && local.Hour() < s.config.BusinessHoursEnd
+}
+
+// isPrivileged indica se a função é administrativa
+func (s *AuditAnomalyServiceImpl) isPrivileged(roleCode string) bool {
+	_, ok := s.privileged[strings.ToUpper(roleCode)]
+	return ok
+}
+
+// isNewAdmin indica se o ator recebeu uma função administrativa dentro da janela de observação
+// As promoções fora da janela são esquecidas
+func (s *AuditAnomalyServiceImpl) isNewAdmin(tenantID uuid.UUID, actorID *uuid.UUID, at time.Time) bool {
+	if actorID == nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := anomalyActorKey{tenantID: tenantID, actorID: *actorID}
+	since, ok := s.adminSince[key]
+	if !ok {
+		return false
+	}
+	if at.Sub(since) > s.config.NewAdminWindow {
+		delete(s.adminSince, key)
+		return false
+	}
+	return true
+}
+
+// openIncident grava o incidente e publica o evento de segurança correspondente
+func (s *AuditAnomalyServiceImpl) openIncident(ctx context.Context, candidate anomalyCandidate) (*model.SecurityIncident, error) {
+	incident, err := model.NewSecurityIncident(
+		candidate.tenantID, candidate.rule, candidate.severity, candidate.actorID,
+		candidate.summary, candidate.evidence, s.now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, incident); err != nil {
+		return nil, fmt.Errorf("erro ao gravar incidente de segurança: %w", err)
+	}
+
+	log.Warn().
+		Str("tenant_id", incident.TenantID.String()).
+		Str("incident_id", incident.ID.String()).
+		Str("rule", string(incident.Rule)).
+		Str("severity", string(incident.Severity)).
+		Msg(incident.Summary)
+
+	if s.publisher != nil {
+		evt := event.NewSecurityAnomalyDetectedEvent(incident)
+		if err := s.publisher.Publish(ctx, evt); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", incident.TenantID.String()).
+				Str("incident_id", incident.ID.String()).
+				Str("event_type", evt.GetType()).
+				Msg("Erro ao publicar evento de anomalia de segurança")
+		}
+	}
+
+	return incident, nil
+}
+
+// ListIncidents recupera os incidentes do tenant que satisfazem o filtro
+func (s *AuditAnomalyServiceImpl) ListIncidents(ctx context.Context, tenantID uuid.UUID, filter model.SecurityIncidentFilter) ([]*model.SecurityIncident, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: estado desconhecido %q", model.ErrInvalidSecurityIncident, filter.Status)
+	}
+	if filter.Severity != "" && !filter.Severity.IsValid() {
+		return nil, fmt.Errorf("%w: severidade desconhecida %q", model.ErrInvalidSecurityIncident, filter.Severity)
+	}
+
+	incidents, err := s.repository.List(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar incidentes de segurança: %w", err)
+	}
+	return incidents, nil
+}
+
+// GetIncident recupera um incidente pelo ID
+func (s *AuditAnomalyServiceImpl) GetIncident(ctx context.Context, tenantID, incidentID uuid.UUID) (*model.SecurityIncident, error) {
+	incident, err := s.repository.Get(ctx, tenantID, incidentID)
+	if err != nil {
+		if errors.Is(err, model.ErrSecurityIncidentNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter incidente de segurança: %w", err)
+	}
+	return incident, nil
+}
+
+// Acknowledge assinala que o incidente está a ser analisado
+func (s *AuditAnomalyServiceImpl) Acknowledge(ctx context.Context, triage *application.SecurityIncidentTriage) (*model.SecurityIncident, error) {
+	return s.triage(ctx, "AuditAnomalyServiceImpl.Acknowledge", triage, (*model.SecurityIncident).Acknowledge)
+}
+
+// Resolve fecha o incidente após a correção
+func (s *AuditAnomalyServiceImpl) Resolve(ctx context.Context, triage *application.SecurityIncidentTriage) (*model.SecurityIncident, error) {
+	return s.triage(ctx, "AuditAnomalyServiceImpl.Resolve", triage, (*model.SecurityIncident).Resolve)
+}
+
+// Dismiss fecha o incidente como falso positivo
+func (s *AuditAnomalyServiceImpl) Dismiss(ctx context.Context, triage *application.SecurityIncidentTriage) (*model.SecurityIncident, error) {
+	return s.triage(ctx, "AuditAnomalyServiceImpl.Dismiss", triage, (*model.SecurityIncident).Dismiss)
+}
+
+// triage aplica a transição ao incidente e grava-a, falhando se outro analista o alterou entretanto
+func (s *AuditAnomalyServiceImpl) triage(
+	ctx context.Context,
+	spanName string,
+	triage *application.SecurityIncidentTriage,
+	transition func(*model.SecurityIncident, uuid.UUID, string, time.Time) error,
+) (*model.SecurityIncident, error) {
+	ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(
+		attribute.String("tenant_id", triage.TenantID.String()),
+		attribute.String("incident_id", triage.IncidentID.String()),
+		attribute.String("actor_id", triage.ActorID.String()),
+	))
+	defer span.End()
+
+	incident, err := s.GetIncident(ctx, triage.TenantID, triage.IncidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := incident.Status
+	if err := transition(incident, triage.ActorID, triage.Note, s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SaveStatus(ctx, incident, expected); err != nil {
+		if errors.Is(err, model.ErrSecurityIncidentConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar triagem do incidente de segurança: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", incident.TenantID.String()).
+		Str("incident_id", incident.ID.String()).
+		Str("actor_id", triage.ActorID.String()).
+		Str("status", string(incident.Status)).
+		Msg("Incidente de segurança triado")
+
+	return incident, nil
+}
+
+// AnomalyDetectingPublisher encaminha os eventos ao publicador seguinte e submete-os à
+// deteção de anomalias, sem que uma falha na análise impeça a publicação
+type AnomalyDetectingPublisher struct {
+	next     event.Publisher
+	analyzer application.AuditAnomalyService
+}
+
+// NewAnomalyDetectingPublisher cria um publicador que observa o fluxo de auditoria
+// O publicador seguinte pode ser nulo quando os eventos não são publicados externamente
+func NewAnomalyDetectingPublisher(next event.Publisher, analyzer application.AuditAnomalyService) event.Publisher {
+	return &AnomalyDetectingPublisher{next: next, analyzer: analyzer}
+}
+
+// Publish publica o evento e analisa-o
+func (p *AnomalyDetectingPublisher) Publish(ctx context.Context, evt event.Event) error {
+	var err error
+	if p.next != nil {
+		err = p.next.Publish(ctx, evt)
+	}
+
+	if _, analyzeErr := p.analyzer.Analyze(ctx, evt); analyzeErr != nil {
+		log.Error().Err(analyzeErr).
+			Str("event_type", evt.GetType()).
+			Msg("Erro ao analisar evento de auditoria")
+	}
+	return err
+}
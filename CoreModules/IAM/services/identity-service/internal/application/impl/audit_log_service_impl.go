@@ -0,0 +1,188 @@
+package impl
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da consulta dos registos de auditoria
+const (
+	DefaultAuditLogPageSize        = 100
+	DefaultAuditLogMaxPageSize     = 1000
+	DefaultAuditLogExportBatchSize = 1000
+	DefaultAuditLogMaxExportRows   = 100000
+)
+
+// AuditLogConfig configura a consulta e a exportação dos registos de auditoria
+type AuditLogConfig struct {
+	// Tamanho da página quando o pedido não indica limite, e o máximo aceite
+	PageSize    int
+	MaxPageSize int
+	// Registos lidos por consulta durante a exportação
+	ExportBatchSize int
+	// Linhas máximas de uma exportação; acima delas a exportação devolve o cursor para continuar
+	MaxExportRows int
+}
+
+// DefaultAuditLogConfig retorna a configuração padrão da consulta dos registos de auditoria
+func DefaultAuditLogConfig() AuditLogConfig {
+	return AuditLogConfig{
+		PageSize:        DefaultAuditLogPageSize,
+		MaxPageSize:     DefaultAuditLogMaxPageSize,
+		ExportBatchSize: DefaultAuditLogExportBatchSize,
+		MaxExportRows:   DefaultAuditLogMaxExportRows,
+	}
+}
+
+// AuditLogServiceImpl implementa a interface AuditLogService
+type AuditLogServiceImpl struct {
+	repository repository.AuditLogRepository
+	config     AuditLogConfig
+}
+
+// NewAuditLogService cria uma nova instância de AuditLogService
+// Os valores fora do intervalo válido usam os padrões
+func NewAuditLogService(repo repository.AuditLogRepository, config AuditLogConfig) application.AuditLogService {
+	defaults := DefaultAuditLogConfig()
+	if config.MaxPageSize <= 0 {
+		config.MaxPageSize = defaults.MaxPageSize
+	}
+	if config.PageSize <= 0 || config.PageSize > config.MaxPageSize {
+		config.PageSize = min(defaults.PageSize, config.MaxPageSize)
+	}
+	if config.ExportBatchSize <= 0 {
+		config.ExportBatchSize = defaults.ExportBatchSize
+	}
+	if config.MaxExportRows <= 0 {
+		config.MaxExportRows = defaults.MaxExportRows
+	}
+
+	return &AuditLogServiceImpl{
+		repository: repo,
+		config:     config,
+	}
+}
+
+// Search recupera uma página dos registos do tenant que satisfazem o filtro
+func (s *AuditLogServiceImpl) Search(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) (*model.AuditLogPage, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogServiceImpl.Search", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.Bool("full_text", filter.Query != ""),
+	))
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.Limit < 0 || filter.Limit > s.config.MaxPageSize {
+		return nil, fmt.Errorf("%w: o limite deve estar entre 1 e %d", model.ErrInvalidAuditLogFilter, s.config.MaxPageSize)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = s.config.PageSize
+	}
+
+	entries, nextCursor, err := s.page(ctx, tenantID, filter)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("entries", len(entries)))
+
+	return &model.AuditLogPage{Entries: entries, NextCursor: nextCursor}, nil
+}
+
+// Export escreve em CSV os registos do tenant que satisfazem o filtro
+func (s *AuditLogServiceImpl) Export(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter, w io.Writer) (*application.AuditLogExportResult, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogServiceImpl.Export", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.Bool("full_text", filter.Query != ""),
+	))
+	defer span.End()
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(model.AuditLogCSVHeader); err != nil {
+		return nil, fmt.Errorf("erro ao escrever exportação dos registos de auditoria: %w", err)
+	}
+
+	result := &application.AuditLogExportResult{}
+	for {
+		filter.Limit = min(s.config.ExportBatchSize, s.config.MaxExportRows-result.Rows)
+		entries, nextCursor, err := s.page(ctx, tenantID, filter)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if err := writer.Write(entry.CSVRecord()); err != nil {
+				return nil, fmt.Errorf("erro ao escrever exportação dos registos de auditoria: %w", err)
+			}
+		}
+		result.Rows += len(entries)
+		// O lote é enviado ao cliente antes da consulta seguinte
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, fmt.Errorf("erro ao escrever exportação dos registos de auditoria: %w", err)
+		}
+
+		if nextCursor == "" {
+			break
+		}
+		if result.Rows >= s.config.MaxExportRows {
+			result.NextCursor = nextCursor
+			break
+		}
+		filter.Cursor = &model.AuditLogCursor{
+			CreatedAt: entries[len(entries)-1].CreatedAt,
+			ID:        entries[len(entries)-1].ID,
+		}
+	}
+	span.SetAttributes(attribute.Int("rows", result.Rows), attribute.Bool("truncated", result.NextCursor != ""))
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Int("rows", result.Rows).
+		Bool("truncated", result.NextCursor != "").
+		Msg("Registos de auditoria exportados")
+
+	return result, nil
+}
+
+// page lê até filter.Limit registos e, se existirem mais, o cursor da página seguinte
+// É pedido um registo adicional para saber se a página é a última
+func (s *AuditLogServiceImpl) page(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) ([]*model.AuditLogEntry, string, error) {
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := s.repository.Search(ctx, tenantID, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao consultar registos de auditoria: %w", err)
+	}
+	if entries == nil {
+		entries = []*model.AuditLogEntry{}
+	}
+	if len(entries) <= limit {
+		return entries, "", nil
+	}
+
+	entries = entries[:limit]
+	last := entries[limit-1]
+	return entries, model.AuditLogCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode(), nil
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre passagens de execução das tarefas de operação em massa
+const DefaultBulkUserJobInterval = 30 * time.Second
+
+// BulkUserJobScheduler executa periodicamente as tarefas de operação em massa pendentes ou interrompidas
+type BulkUserJobScheduler struct {
+	service  application.BulkUserJobService
+	interval time.Duration
+}
+
+// NewBulkUserJobScheduler cria o agendador da execução das tarefas de operação em massa
+// Um intervalo não positivo usa DefaultBulkUserJobInterval
+func NewBulkUserJobScheduler(service application.BulkUserJobService, interval time.Duration) *BulkUserJobScheduler {
+	if interval <= 0 {
+		interval = DefaultBulkUserJobInterval
+	}
+	return &BulkUserJobScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start executa as tarefas no arranque e a cada intervalo até o contexto ser cancelado
+// As tarefas interrompidas pelo cancelamento continuam nos itens pendentes no arranque seguinte
+func (s *BulkUserJobScheduler) Start(ctx context.Context) {
+	// A passagem executa as tarefas pendentes de todos os tenants
+	ctx = requestctx.WithSystemScope(ctx, "bulk_user_job_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *BulkUserJobScheduler) run(ctx context.Context) {
+	result, err := s.service.RunPending(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao executar tarefas de operação em massa sobre usuários")
+		return
+	}
+	if result.Processed == 0 {
+		return
+	}
+
+	log.Info().
+		Int("processed", result.Processed).
+		Int("completed", result.Completed).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Passagem de execução de tarefas de operação em massa concluída")
+}
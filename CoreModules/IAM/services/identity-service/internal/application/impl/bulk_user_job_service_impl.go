@@ -0,0 +1,576 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das operações em massa sobre usuários
+const (
+	DefaultBulkUserJobBatchSize = 100
+	DefaultBulkUserJobRunLimit  = 10
+	DefaultBulkUserJobMaxItems  = 50000
+	DefaultBulkUserJobPageSize  = 100
+	MaxBulkUserJobPageSize      = 1000
+)
+
+// Tamanho máximo de uma linha do conteúdo NDJSON
+const bulkUserMaxLineSize = 64 << 10
+
+// Colunas aceites no conteúdo CSV
+var bulkUserCSVColumns = map[string]bool{"user_id": true, "role_id": true, "reason": true, "expires_at": true}
+
+// BulkUserJobConfig configura a execução das tarefas de operação em massa
+type BulkUserJobConfig struct {
+	// Número de itens processados por lote; os resultados são gravados após cada lote
+	BatchSize int
+	// Número máximo de tarefas executadas por passagem do agendador
+	RunLimit int
+	// Número máximo de usuários numa tarefa
+	MaxItems int
+}
+
+// bulkUserLine representa um item do conteúdo enviado
+type bulkUserLine struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	RoleID    *uuid.UUID `json:"role_id,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BulkUserJobServiceImpl implementa a interface BulkUserJobService
+// O estado das contas é alterado pelo ciclo de vida de usuários e as atribuições pelo serviço
+// de funções, que aplicam as mesmas regras e limites das operações individuais
+type BulkUserJobServiceImpl struct {
+	repository repository.BulkUserJobRepository
+	users      repository.UserLifecycleRepository
+	lifecycle  application.UserLifecycleService
+	roles      application.RoleService
+	config     BulkUserJobConfig
+
+	now func() time.Time
+}
+
+// NewBulkUserJobService cria uma nova instância de BulkUserJobService
+func NewBulkUserJobService(
+	repo repository.BulkUserJobRepository,
+	users repository.UserLifecycleRepository,
+	lifecycle application.UserLifecycleService,
+	roles application.RoleService,
+	config BulkUserJobConfig,
+) application.BulkUserJobService {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBulkUserJobBatchSize
+	}
+	if config.RunLimit <= 0 {
+		config.RunLimit = DefaultBulkUserJobRunLimit
+	}
+	if config.MaxItems <= 0 {
+		config.MaxItems = DefaultBulkUserJobMaxItems
+	}
+
+	return &BulkUserJobServiceImpl{
+		repository: repo,
+		users:      users,
+		lifecycle:  lifecycle,
+		roles:      roles,
+		config:     config,
+		now:        time.Now,
+	}
+}
+
+// Submit valida o conteúdo enviado e regista uma tarefa pendente
+func (s *BulkUserJobServiceImpl) Submit(ctx context.Context, req *application.SubmitBulkUserJobRequest) (*model.BulkUserJob, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobServiceImpl.Submit", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("operation", string(req.Operation)),
+		attribute.String("format", string(req.Format)),
+	))
+	defer span.End()
+
+	if req.Payload == nil {
+		return nil, fmt.Errorf("%w: conteúdo vazio", model.ErrInvalidBulkUserJob)
+	}
+
+	var (
+		items []*model.BulkUserJobItem
+		err   error
+	)
+	switch req.Format {
+	case application.BulkUserPayloadNDJSON:
+		items, err = s.parseNDJSON(req.Payload)
+	case application.BulkUserPayloadCSV:
+		items, err = s.parseCSV(req.Payload)
+	default:
+		err = fmt.Errorf("%w: formato desconhecido %q", model.ErrInvalidBulkUserJob, req.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.RoleID == nil && req.RoleID != nil {
+			roleID := *req.RoleID
+			item.RoleID = &roleID
+		}
+		if item.Reason == "" {
+			item.Reason = req.Reason
+		}
+		if item.ExpiresAt == nil && req.ExpiresAt != nil {
+			expiresAt := *req.ExpiresAt
+			item.ExpiresAt = &expiresAt
+		}
+	}
+
+	job, err := model.NewBulkUserJob(req.TenantID, req.RequestedBy, req.Operation, items, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, job, items); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, fmt.Errorf("erro ao registar tarefa de operação em massa: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", job.TenantID.String()).
+		Str("job_id", job.ID.String()).
+		Str("operation", string(job.Operation)).
+		Int("total", job.Total).
+		Str("requested_by", job.RequestedBy.String()).
+		Msg("Tarefa de operação em massa sobre usuários registada")
+
+	return job, nil
+}
+
+// Get recupera uma tarefa pelo ID
+func (s *BulkUserJobServiceImpl) Get(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error) {
+	job, err := s.repository.Get(ctx, tenantID, jobID)
+	if err != nil {
+		if errors.Is(err, model.ErrBulkUserJobNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter tarefa de operação em massa: %w", err)
+	}
+	return job, nil
+}
+
+// List recupera as tarefas do tenant
+func (s *BulkUserJobServiceImpl) List(ctx context.Context, tenantID uuid.UUID) ([]*model.BulkUserJob, error) {
+	jobs, err := s.repository.List(ctx, tenantID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tarefas de operação em massa: %w", err)
+	}
+	return jobs, nil
+}
+
+// ListItems recupera uma página dos itens da tarefa
+func (s *BulkUserJobServiceImpl) ListItems(
+	ctx context.Context,
+	tenantID, jobID uuid.UUID,
+	status model.BulkUserItemStatus,
+	after, limit int,
+) (*application.BulkUserJobItemPage, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: resultado desconhecido %q", model.ErrInvalidBulkUserJob, status)
+	}
+	if limit <= 0 {
+		limit = DefaultBulkUserJobPageSize
+	}
+	if limit > MaxBulkUserJobPageSize {
+		limit = MaxBulkUserJobPageSize
+	}
+
+	// Confirmar que a tarefa existe no tenant antes de ler os itens
+	if _, err := s.Get(ctx, tenantID, jobID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repository.ListItems(ctx, tenantID, jobID, status, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens da tarefa de operação em massa: %w", err)
+	}
+
+	page := &application.BulkUserJobItemPage{Items: items}
+	if page.Items == nil {
+		page.Items = []*model.BulkUserJobItem{}
+	}
+	if len(items) == limit {
+		page.NextAfter = items[len(items)-1].Line
+	}
+	return page, nil
+}
+
+// Resume devolve à fila uma tarefa falhada ou concluída com erros
+func (s *BulkUserJobServiceImpl) Resume(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error) {
+	job, err := s.Get(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := job.Version
+	if err := job.Resume(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.Requeue(ctx, job, version); err != nil {
+		if errors.Is(err, model.ErrBulkUserJobConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao retomar tarefa de operação em massa: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", job.TenantID.String()).
+		Str("job_id", job.ID.String()).
+		Int("pending", job.Total-job.Processed).
+		Msg("Tarefa de operação em massa sobre usuários retomada")
+
+	return job, nil
+}
+
+// Run processa os itens pendentes lote a lote, gravando os resultados após cada lote
+// O cancelamento do contexto interrompe a execução sem a marcar como falhada; os itens
+// já processados são gravados e os restantes continuam pendentes
+func (s *BulkUserJobServiceImpl) Run(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobServiceImpl.Run", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("job_id", jobID.String()),
+	))
+	defer span.End()
+
+	job, err := s.Get(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !job.IsActive() {
+		return job, nil
+	}
+
+	if err := job.Start(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.save(ctx, job, job.Version); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return job, err
+		}
+
+		items, err := s.repository.ListItems(ctx, job.TenantID, job.ID, model.BulkUserItemStatusPending, 0, s.config.BatchSize)
+		if err != nil {
+			return s.fail(ctx, span, job, fmt.Errorf("erro ao ler itens pendentes: %w", err))
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		processed := make([]*model.BulkUserJobItem, 0, len(items))
+		for _, item := range items {
+			s.apply(ctx, job, item)
+			if ctx.Err() != nil {
+				// O resultado de um item interrompido não é fiável; o item fica pendente
+				break
+			}
+			job.Record(item)
+			processed = append(processed, item)
+		}
+		if len(processed) == 0 {
+			return job, ctx.Err()
+		}
+
+		// Os resultados das operações já aplicadas são gravados mesmo após o cancelamento,
+		// para que cada usuário afetado tenha o seu registo de auditoria
+		job.UpdatedAt = s.now()
+		if err := s.repository.SaveResults(context.WithoutCancel(ctx), job, processed, job.Version); err != nil {
+			// Os itens continuam pendentes e são reavaliados na passagem seguinte; as operações
+			// já aplicadas resultam então em itens ignorados
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+			if errors.Is(err, model.ErrBulkUserJobConflict) {
+				return job, err
+			}
+			return job, fmt.Errorf("erro ao gravar resultados da tarefa de operação em massa: %w", err)
+		}
+	}
+
+	job.Complete(s.now())
+	if err := s.save(ctx, job, job.Version); err != nil {
+		return job, err
+	}
+
+	log.Info().
+		Str("tenant_id", job.TenantID.String()).
+		Str("job_id", job.ID.String()).
+		Str("operation", string(job.Operation)).
+		Str("status", string(job.Status)).
+		Int("succeeded", job.Succeeded).
+		Int("skipped", job.Skipped).
+		Int("failed", job.Failed).
+		Int("attempts", job.Attempts).
+		Msg("Tarefa de operação em massa sobre usuários concluída")
+
+	return job, nil
+}
+
+// RunPending processa as tarefas pendentes ou interrompidas de todos os tenants
+func (s *BulkUserJobServiceImpl) RunPending(ctx context.Context) (*application.BulkUserJobRunResult, error) {
+	result := &application.BulkUserJobRunResult{StartedAt: s.now()}
+
+	jobs, err := s.repository.ListActive(ctx, s.config.RunLimit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tarefas de operação em massa pendentes: %w", err)
+	}
+
+	for _, pending := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		job, err := s.Run(ctx, pending.TenantID, pending.ID)
+		if errors.Is(err, model.ErrBulkUserJobConflict) {
+			// Outra instância está a executar a tarefa
+			continue
+		}
+		result.Processed++
+		switch {
+		case err == nil && job != nil && !job.IsActive():
+			result.Completed++
+		case job != nil && job.Status == model.BulkUserJobStatusFailed:
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// apply aplica a operação da tarefa ao usuário do item e regista o resultado no item
+// O usuário é lido antes da operação para confirmar que pertence ao tenant e para que
+// uma operação já aplicada resulte num item ignorado e não numa falha
+func (s *BulkUserJobServiceImpl) apply(ctx context.Context, job *model.BulkUserJob, item *model.BulkUserJobItem) {
+	user, err := s.users.GetUser(ctx, job.TenantID, item.UserID)
+	if err != nil {
+		item.Fail(bulkUserItemCode(err), err.Error(), s.now())
+		return
+	}
+
+	actorID := job.RequestedBy
+	if target, ok := job.Operation.TargetStatus(); ok {
+		if user.Status == target {
+			item.Skip(model.BulkUserItemCodeAlreadyApplied, fmt.Sprintf("usuário já no estado %s", target), s.now())
+			return
+		}
+		_, err = s.lifecycle.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: job.TenantID,
+			UserID:   item.UserID,
+			Target:   target,
+			Reason:   item.Reason,
+			ActorID:  &actorID,
+		})
+	} else {
+		switch job.Operation {
+		case model.BulkUserOperationAssignRole:
+			err = s.roles.AssignUserToRole(ctx, job.TenantID, *item.RoleID, item.UserID, s.now(), item.ExpiresAt, actorID)
+			if errors.Is(err, application.ErrUserAlreadyAssigned) {
+				item.Skip(model.BulkUserItemCodeAlreadyApplied, "função já atribuída ao usuário", s.now())
+				return
+			}
+		case model.BulkUserOperationRevokeRole:
+			err = s.roles.RevokeUserFromRole(ctx, job.TenantID, *item.RoleID, item.UserID, actorID)
+			if errors.Is(err, application.ErrUserNotAssigned) {
+				item.Skip(model.BulkUserItemCodeAlreadyApplied, "função não atribuída ao usuário", s.now())
+				return
+			}
+		}
+	}
+
+	if err != nil {
+		item.Fail(bulkUserItemCode(err), err.Error(), s.now())
+		return
+	}
+	item.Succeed(s.now())
+}
+
+// bulkUserItemCode classifica o erro da operação sobre um item
+func bulkUserItemCode(err error) string {
+	switch {
+	case errors.Is(err, model.ErrUserNotFound):
+		return model.BulkUserItemCodeUserNotFound
+	case errors.Is(err, application.ErrRoleNotFound):
+		return model.BulkUserItemCodeRoleNotFound
+	case errors.Is(err, model.ErrInvalidUserTransition),
+		errors.Is(err, model.ErrUserTransitionReasonMissing),
+		errors.Is(err, model.ErrUserAccountDeprovisioned):
+		return model.BulkUserItemCodeInvalidTransition
+	case errors.Is(err, application.ErrPermissionBoundaryViolation):
+		return model.BulkUserItemCodeBoundaryExceeded
+	}
+	return model.BulkUserItemCodeError
+}
+
+// parseNDJSON lê um item por linha; as linhas em branco são ignoradas
+func (s *BulkUserJobServiceImpl) parseNDJSON(payload io.Reader) ([]*model.BulkUserJobItem, error) {
+	scanner := bufio.NewScanner(payload)
+	scanner.Buffer(make([]byte, 0, 4096), bulkUserMaxLineSize)
+
+	var items []*model.BulkUserJobItem
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var line bulkUserLine
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&line); err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %v", model.ErrInvalidBulkUserJob, lineNumber, err)
+		}
+
+		if len(items) == s.config.MaxItems {
+			return nil, fmt.Errorf("%w: mais de %d usuários", model.ErrInvalidBulkUserJob, s.config.MaxItems)
+		}
+		items = append(items, line.item(lineNumber))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidBulkUserJob, err)
+	}
+	return items, nil
+}
+
+// parseCSV lê um item por registo, com as colunas indicadas pela linha de cabeçalho
+func (s *BulkUserJobServiceImpl) parseCSV(payload io.Reader) ([]*model.BulkUserJobItem, error) {
+	reader := csv.NewReader(payload)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: cabeçalho: %v", model.ErrInvalidBulkUserJob, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !bulkUserCSVColumns[name] {
+			return nil, fmt.Errorf("%w: coluna desconhecida %q", model.ErrInvalidBulkUserJob, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["user_id"]; !ok {
+		return nil, fmt.Errorf("%w: coluna user_id obrigatória", model.ErrInvalidBulkUserJob)
+	}
+
+	var items []*model.BulkUserJobItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidBulkUserJob, err)
+		}
+		lineNumber, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		var line bulkUserLine
+		if line.UserID, err = uuid.Parse(field("user_id")); err != nil {
+			return nil, fmt.Errorf("%w: linha %d: user_id inválido", model.ErrInvalidBulkUserJob, lineNumber)
+		}
+		if value := field("role_id"); value != "" {
+			roleID, err := uuid.Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: linha %d: role_id inválido", model.ErrInvalidBulkUserJob, lineNumber)
+			}
+			line.RoleID = &roleID
+		}
+		line.Reason = field("reason")
+		if value := field("expires_at"); value != "" {
+			expiresAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: linha %d: expires_at deve estar no formato RFC 3339", model.ErrInvalidBulkUserJob, lineNumber)
+			}
+			line.ExpiresAt = &expiresAt
+		}
+
+		if len(items) == s.config.MaxItems {
+			return nil, fmt.Errorf("%w: mais de %d usuários", model.ErrInvalidBulkUserJob, s.config.MaxItems)
+		}
+		items = append(items, line.item(lineNumber))
+	}
+	return items, nil
+}
+
+// item converte a linha num item da tarefa
+func (l bulkUserLine) item(lineNumber int) *model.BulkUserJobItem {
+	return &model.BulkUserJobItem{
+		Line:      lineNumber,
+		UserID:    l.UserID,
+		RoleID:    l.RoleID,
+		Reason:    strings.TrimSpace(l.Reason),
+		ExpiresAt: l.ExpiresAt,
+	}
+}
+
+// fail regista a falha da passagem; os itens sem resultado continuam pendentes
+func (s *BulkUserJobServiceImpl) fail(ctx context.Context, span trace.Span, job *model.BulkUserJob, cause error) (*model.BulkUserJob, error) {
+	span.SetStatus(codes.Error, cause.Error())
+	span.RecordError(cause)
+
+	if ctx.Err() != nil {
+		return job, cause
+	}
+
+	job.Fail(cause.Error(), s.now())
+	if err := s.save(ctx, job, job.Version); err != nil {
+		log.Error().Err(err).
+			Str("job_id", job.ID.String()).
+			Msg("Erro ao registar falha da tarefa de operação em massa")
+	}
+
+	log.Error().Err(cause).
+		Str("tenant_id", job.TenantID.String()).
+		Str("job_id", job.ID.String()).
+		Msg("Falha na execução da tarefa de operação em massa sobre usuários")
+
+	return job, cause
+}
+
+// save grava a tarefa, preservando o erro de conflito entre instâncias
+func (s *BulkUserJobServiceImpl) save(ctx context.Context, job *model.BulkUserJob, version int64) error {
+	if err := s.repository.Save(ctx, job, version); err != nil {
+		if errors.Is(err, model.ErrBulkUserJobConflict) {
+			return err
+		}
+		return fmt.Errorf("erro ao gravar tarefa de operação em massa: %w", err)
+	}
+	return nil
+}
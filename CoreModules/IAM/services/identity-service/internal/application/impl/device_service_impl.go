@@ -0,0 +1,549 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão do registo de dispositivos
+const (
+	DefaultDeviceReuseWindow = 30 * 24 * time.Hour
+)
+
+// Tamanho mínimo da chave que protege os identificadores dos dispositivos
+const MinDeviceSigningKeySize = 32
+
+// Tamanho máximo do agente gravado com o dispositivo
+const maxDeviceUserAgentSize = 512
+
+// DeviceConfig configura o registo de dispositivos
+type DeviceConfig struct {
+	// Chave HMAC das impressões dos dispositivos
+	SigningKey []byte
+	// Período em que os dispositivos das outras contas contam para a deteção de partilha
+	ReuseWindow time.Duration
+}
+
+// DefaultDeviceConfig retorna a configuração padrão do registo de dispositivos
+// A chave de assinatura não tem valor padrão
+func DefaultDeviceConfig() DeviceConfig {
+	return DeviceConfig{
+		ReuseWindow: DefaultDeviceReuseWindow,
+	}
+}
+
+// DeviceServiceImpl implementa a interface DeviceService
+type DeviceServiceImpl struct {
+	repository repository.DeviceRepository
+	incidents  repository.SecurityIncidentRepository
+	publisher  event.Publisher
+	config     DeviceConfig
+	now        func() time.Time
+}
+
+// NewDeviceService cria uma nova instância de DeviceService
+// As alterações dos dispositivos são publicadas no publicador indicado e a partilha de um dispositivo
+// entre contas é gravada como incidente de segurança; ambos podem ser nulos. A chave de assinatura
+// deve ter pelo menos MinDeviceSigningKeySize bytes
+func NewDeviceService(
+	repo repository.DeviceRepository,
+	incidents repository.SecurityIncidentRepository,
+	publisher event.Publisher,
+	config DeviceConfig,
+) application.DeviceService {
+	if config.ReuseWindow <= 0 {
+		config.ReuseWindow = DefaultDeviceReuseWindow
+	}
+
+	return &DeviceServiceImpl{
+		repository: repo,
+		incidents:  incidents,
+		publisher:  publisher,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GetPolicy recupera a política de dispositivos do tenant
+// Os tenants que nunca a configuraram têm a confiança em dispositivos desativada
+func (s *DeviceServiceImpl) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.DevicePolicy, error) {
+	policy, err := s.repository.GetPolicy(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter política de dispositivos: %w", err)
+	}
+	if policy == nil {
+		return model.DefaultDevicePolicy(tenantID), nil
+	}
+	return policy, nil
+}
+
+// UpdatePolicy altera a política de dispositivos do tenant
+func (s *DeviceServiceImpl) UpdatePolicy(ctx context.Context, req *application.UpdateDevicePolicyRequest) (*model.DevicePolicy, error) {
+	ctx, span := tracer.Start(ctx, "DeviceServiceImpl.UpdatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.Bool("trust_enabled", req.TrustEnabled),
+	))
+	defer span.End()
+
+	policy := &model.DevicePolicy{
+		TenantID:          req.TenantID,
+		TrustEnabled:      req.TrustEnabled,
+		TrustDays:         req.TrustDays,
+		MaxTrustedDevices: req.MaxTrustedDevices,
+		ReuseThreshold:    req.ReuseThreshold,
+		UpdatedBy:         req.UpdatedBy,
+		UpdatedAt:         s.now(),
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SavePolicy(ctx, policy); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar política de dispositivos: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", policy.TenantID.String()).
+		Bool("trust_enabled", policy.TrustEnabled).
+		Int("trust_days", policy.TrustDays).
+		Int("max_trusted_devices", policy.MaxTrustedDevices).
+		Int("reuse_threshold", policy.ReuseThreshold).
+		Str("updated_by", policy.UpdatedBy.String()).
+		Msg("Política de dispositivos atualizada")
+
+	return policy, nil
+}
+
+// ObserveLogin regista o dispositivo do login no registo do usuário
+//
+// Um dispositivo novo é publicado no fluxo de auditoria e, quando tem identificador, é comparado
+// com os dispositivos das outras contas do tenant: a partir do limiar da política, a partilha é
+// sinalizada no dispositivo, publicada e gravada como incidente de segurança.
+func (s *DeviceServiceImpl) ObserveLogin(ctx context.Context, evt *model.LoginEvent) error {
+	ctx, span := tracer.Start(ctx, "DeviceServiceImpl.ObserveLogin", trace.WithAttributes(
+		attribute.String("tenant_id", evt.TenantID.String()),
+		attribute.String("method", evt.Method),
+	))
+	defer span.End()
+
+	fingerprint, reuseKey := s.fingerprints(evt)
+	if fingerprint == "" {
+		return nil
+	}
+
+	now := s.now()
+	seenAt := evt.OccurredAt
+	if seenAt.IsZero() {
+		seenAt = now
+	}
+	userAgent := strings.TrimSpace(evt.UserAgent)
+	if runes := []rune(userAgent); len(runes) > maxDeviceUserAgentSize {
+		userAgent = string(runes[:maxDeviceUserAgentSize])
+	}
+	device := &model.Device{
+		ID:            uuid.New(),
+		TenantID:      evt.TenantID,
+		UserID:        evt.UserID,
+		Name:          model.DefaultDeviceName(userAgent),
+		Fingerprint:   fingerprint,
+		ReuseKey:      reuseKey,
+		Identified:    reuseKey != "",
+		UserAgent:     userAgent,
+		LastIPAddress: evt.IPAddress,
+		LastCountry:   strings.ToUpper(strings.TrimSpace(evt.Country)),
+		LastMethod:    evt.Method,
+		Status:        model.DeviceStatusActive,
+		LoginCount:    1,
+		FirstSeenAt:   seenAt,
+		LastSeenAt:    seenAt,
+	}
+
+	registered, err := s.repository.RecordSighting(ctx, device)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao registar dispositivo do login: %w", err)
+	}
+	span.SetAttributes(attribute.Bool("device.registered", registered))
+	if !registered {
+		return nil
+	}
+
+	log.Info().
+		Str("tenant_id", device.TenantID.String()).
+		Str("user_id", device.UserID.String()).
+		Str("device_id", device.ID.String()).
+		Str("device_name", device.Name).
+		Bool("identified", device.Identified).
+		Str("ip_address", device.LastIPAddress).
+		Msg("Novo dispositivo registado para o usuário")
+	s.publish(ctx, event.NewDeviceEvent(event.TopicDeviceRegistered, device, nil, seenAt))
+
+	if device.ReuseKey != "" {
+		if err := s.detectReuse(ctx, device, now); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// EvaluateDeviceTrust indica se o login vem de um dispositivo de confiança do usuário
+// Apenas os dispositivos com identificador, ativos, com a confiança válida e não partilhados
+// com outras contas dispensam o segundo fator
+func (s *DeviceServiceImpl) EvaluateDeviceTrust(ctx context.Context, evt *model.LoginEvent) (*model.DeviceTrustDecision, error) {
+	ctx, span := tracer.Start(ctx, "DeviceServiceImpl.EvaluateDeviceTrust", trace.WithAttributes(
+		attribute.String("tenant_id", evt.TenantID.String()),
+	))
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, evt.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.TrustEnabled {
+		return &model.DeviceTrustDecision{Reason: model.DeviceTrustReasonPolicyOff}, nil
+	}
+	fingerprint, reuseKey := s.fingerprints(evt)
+	if reuseKey == "" {
+		return &model.DeviceTrustDecision{Reason: model.DeviceTrustReasonUnidentified}, nil
+	}
+
+	device, err := s.repository.FindByFingerprint(ctx, evt.TenantID, evt.UserID, fingerprint)
+	if err != nil {
+		if errors.Is(err, model.ErrDeviceNotFound) {
+			return &model.DeviceTrustDecision{Reason: model.DeviceTrustReasonUnknown}, nil
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao obter dispositivo do login: %w", err)
+	}
+
+	decision := &model.DeviceTrustDecision{DeviceID: &device.ID, TrustExpiresAt: device.TrustExpiresAt}
+	now := s.now()
+	switch {
+	case device.Status == model.DeviceStatusRevoked:
+		decision.Reason = model.DeviceTrustReasonRevoked
+	case device.TrustExpiresAt == nil:
+		decision.Reason = model.DeviceTrustReasonNotTrusted
+	case !device.IsTrusted(now):
+		decision.Reason = model.DeviceTrustReasonExpired
+	default:
+		shared, err := s.sharedAccounts(ctx, device, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(shared) >= policy.ReuseThreshold {
+			decision.Reason = model.DeviceTrustReasonShared
+		} else {
+			decision.Trusted = true
+			decision.Reason = model.DeviceTrustReasonTrusted
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Bool("device.trusted", decision.Trusted),
+		attribute.String("device.trust_reason", decision.Reason),
+	)
+	return decision, nil
+}
+
+// ListDevices recupera os dispositivos do usuário, incluindo os revogados
+func (s *DeviceServiceImpl) ListDevices(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.Device, error) {
+	devices, err := s.repository.List(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar dispositivos: %w", err)
+	}
+	return devices, nil
+}
+
+// RenameDevice altera o nome apresentado de um dispositivo
+func (s *DeviceServiceImpl) RenameDevice(ctx context.Context, req *application.DeviceActionRequest, name string) (*model.Device, error) {
+	name, err := model.NormalizeDeviceName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if device.Name == name {
+		return device, nil
+	}
+	device.Name = name
+
+	if err := s.update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, event.NewDeviceEvent(event.TopicDeviceRenamed, device, &req.ActorID, s.now()))
+	return device, nil
+}
+
+// TrustDevice marca um dispositivo como de confiança pela validade da política do tenant
+func (s *DeviceServiceImpl) TrustDevice(ctx context.Context, req *application.DeviceActionRequest) (*model.Device, error) {
+	ctx, span := tracer.Start(ctx, "DeviceServiceImpl.TrustDevice", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("device_id", req.DeviceID.String()),
+	))
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.TrustEnabled {
+		return nil, model.ErrDeviceTrustDisabled
+	}
+
+	device, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == model.DeviceStatusRevoked {
+		return nil, model.ErrDeviceRevoked
+	}
+	if !device.Identified {
+		return nil, fmt.Errorf("%w: o dispositivo não tem identificador próprio", model.ErrDeviceTrustForbidden)
+	}
+
+	now := s.now()
+	shared, err := s.sharedAccounts(ctx, device, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(shared) >= policy.ReuseThreshold {
+		return nil, fmt.Errorf("%w: o dispositivo é usado por %d contas do tenant", model.ErrDeviceTrustForbidden, len(shared))
+	}
+
+	devices, err := s.repository.List(ctx, req.TenantID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar dispositivos: %w", err)
+	}
+	trusted := 0
+	for _, other := range devices {
+		if other.ID != device.ID && other.IsTrusted(now) {
+			trusted++
+		}
+	}
+	if trusted >= policy.MaxTrustedDevices {
+		return nil, fmt.Errorf("%w: máximo de %d dispositivos", model.ErrTrustedDeviceLimit, policy.MaxTrustedDevices)
+	}
+
+	expiresAt := now.Add(policy.TrustDuration())
+	actorID := req.ActorID
+	device.TrustedAt = &now
+	device.TrustExpiresAt = &expiresAt
+	device.TrustedBy = &actorID
+
+	if err := s.update(ctx, device); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", device.TenantID.String()).
+		Str("user_id", device.UserID.String()).
+		Str("device_id", device.ID.String()).
+		Str("actor_id", actorID.String()).
+		Time("trust_expires_at", expiresAt).
+		Msg("Dispositivo marcado como de confiança")
+	s.publish(ctx, event.NewDeviceEvent(event.TopicDeviceTrusted, device, &actorID, now))
+
+	return device, nil
+}
+
+// UntrustDevice retira a confiança num dispositivo; retirar a confiança de um dispositivo
+// que não a tem não altera nada
+func (s *DeviceServiceImpl) UntrustDevice(ctx context.Context, req *application.DeviceActionRequest) (*model.Device, error) {
+	device, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == model.DeviceStatusRevoked {
+		return nil, model.ErrDeviceRevoked
+	}
+	if device.TrustedAt == nil {
+		return device, nil
+	}
+	device.ClearTrust()
+
+	if err := s.update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", device.TenantID.String()).
+		Str("user_id", device.UserID.String()).
+		Str("device_id", device.ID.String()).
+		Str("actor_id", req.ActorID.String()).
+		Msg("Confiança no dispositivo retirada")
+	s.publish(ctx, event.NewDeviceEvent(event.TopicDeviceUntrusted, device, &req.ActorID, s.now()))
+
+	return device, nil
+}
+
+// RevokeDevice revoga um dispositivo e retira-lhe a confiança; revogar um dispositivo já
+// revogado não altera nada
+func (s *DeviceServiceImpl) RevokeDevice(ctx context.Context, req *application.DeviceActionRequest) (*model.Device, error) {
+	device, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if device.Status == model.DeviceStatusRevoked {
+		return device, nil
+	}
+
+	now := s.now()
+	actorID := req.ActorID
+	device.Status = model.DeviceStatusRevoked
+	device.ClearTrust()
+	device.RevokedAt = &now
+	device.RevokedBy = &actorID
+
+	if err := s.update(ctx, device); err != nil {
+		return nil, err
+	}
+
+	log.Warn().
+		Str("tenant_id", device.TenantID.String()).
+		Str("user_id", device.UserID.String()).
+		Str("device_id", device.ID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Dispositivo revogado")
+	s.publish(ctx, event.NewDeviceEvent(event.TopicDeviceRevoked, device, &actorID, now))
+
+	return device, nil
+}
+
+// detectReuse sinaliza o dispositivo quando é usado por pelo menos o limiar de contas da política
+func (s *DeviceServiceImpl) detectReuse(ctx context.Context, device *model.Device, now time.Time) error {
+	policy, err := s.GetPolicy(ctx, device.TenantID)
+	if err != nil {
+		return err
+	}
+	accounts, err := s.sharedAccounts(ctx, device, now)
+	if err != nil {
+		return err
+	}
+	if len(accounts) < policy.ReuseThreshold {
+		return nil
+	}
+
+	device.ReuseDetectedAt = &now
+	if err := s.update(ctx, device); err != nil {
+		return err
+	}
+
+	log.Warn().
+		Str("tenant_id", device.TenantID.String()).
+		Str("user_id", device.UserID.String()).
+		Str("device_id", device.ID.String()).
+		Int("accounts", len(accounts)).
+		Str("ip_address", device.LastIPAddress).
+		Msg("Dispositivo usado por várias contas do tenant")
+
+	evt := event.NewDeviceEvent(event.TopicDeviceFingerprintReuse, device, nil, now)
+	evt.SharedWith = accounts
+	s.publish(ctx, evt)
+
+	if s.incidents == nil {
+		return nil
+	}
+	incident, err := model.NewDeviceFingerprintReuseIncident(device, accounts, now)
+	if err != nil {
+		return err
+	}
+	if err := s.incidents.Create(ctx, incident); err != nil {
+		return fmt.Errorf("erro ao gravar incidente de segurança: %w", err)
+	}
+	s.publish(ctx, event.NewSecurityAnomalyDetectedEvent(incident))
+	return nil
+}
+
+// sharedAccounts recupera as contas do tenant que usaram o dispositivo durante o período de partilha
+func (s *DeviceServiceImpl) sharedAccounts(ctx context.Context, device *model.Device, now time.Time) ([]uuid.UUID, error) {
+	if device.ReuseKey == "" {
+		return nil, nil
+	}
+	accounts, err := s.repository.ListAccountsByReuseKey(ctx, device.TenantID, device.ReuseKey, now.Add(-s.config.ReuseWindow))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar contas do dispositivo: %w", err)
+	}
+	return accounts, nil
+}
+
+// get recupera o dispositivo do pedido
+func (s *DeviceServiceImpl) get(ctx context.Context, req *application.DeviceActionRequest) (*model.Device, error) {
+	device, err := s.repository.Get(ctx, req.TenantID, req.UserID, req.DeviceID)
+	if err != nil {
+		if errors.Is(err, model.ErrDeviceNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter dispositivo: %w", err)
+	}
+	return device, nil
+}
+
+// update grava o dispositivo alterado
+func (s *DeviceServiceImpl) update(ctx context.Context, device *model.Device) error {
+	if err := s.repository.Update(ctx, device); err != nil {
+		if errors.Is(err, model.ErrDeviceNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao gravar dispositivo: %w", err)
+	}
+	return nil
+}
+
+// publish publica o evento no fluxo de auditoria; as falhas são apenas registadas
+func (s *DeviceServiceImpl) publish(ctx context.Context, evt event.Event) {
+	if s.publisher == nil {
+		return
+	}
+	if err := s.publisher.Publish(ctx, evt); err != nil {
+		log.Error().Err(err).
+			Str("event_type", evt.GetType()).
+			Msg("Erro ao publicar evento do registo de dispositivos")
+	}
+}
+
+// fingerprints calcula a impressão do dispositivo do login, por usuário, e a chave de partilha,
+// comum a todo o tenant. A chave só existe quando o cliente indica o identificador do dispositivo;
+// sem dispositivo conhecido, ambas são vazias
+func (s *DeviceServiceImpl) fingerprints(evt *model.LoginEvent) (fingerprint, reuseKey string) {
+	key := evt.DeviceKey()
+	if key == "" {
+		return "", ""
+	}
+	tenant := evt.TenantID.String() + "|"
+	fingerprint = s.sign("device|user", tenant+evt.UserID.String()+"|"+key)
+	if evt.DeviceID != "" {
+		reuseKey = s.sign("device|reuse", tenant+evt.DeviceID)
+	}
+	return fingerprint, reuseKey
+}
+
+// sign calcula o HMAC do valor no domínio indicado, em base64url
+func (s *DeviceServiceImpl) sign(domain, value string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	mac.Write([]byte(domain + "|" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre execuções das desativações automáticas das contas de emergência
+const DefaultEmergencyAccessSweepInterval = time.Minute
+
+// EmergencyAccessScheduler termina periodicamente as ativações expiradas das contas de emergência
+// e recusa os pedidos de ativação não aprovados no prazo
+type EmergencyAccessScheduler struct {
+	service  application.EmergencyAccessService
+	interval time.Duration
+}
+
+// NewEmergencyAccessScheduler cria o agendador das desativações automáticas
+// Um intervalo não positivo usa DefaultEmergencyAccessSweepInterval
+func NewEmergencyAccessScheduler(service application.EmergencyAccessService, interval time.Duration) *EmergencyAccessScheduler {
+	if interval <= 0 {
+		interval = DefaultEmergencyAccessSweepInterval
+	}
+	return &EmergencyAccessScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start executa as desativações no arranque e a cada intervalo até o contexto ser cancelado
+func (s *EmergencyAccessScheduler) Start(ctx context.Context) {
+	// A desativação das contas de emergência percorre as ativações de todos os tenants
+	ctx = requestctx.WithSystemScope(ctx, "emergency_access_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *EmergencyAccessScheduler) run(ctx context.Context) {
+	result, err := s.service.RunScheduledDeactivations(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao executar desativações automáticas das contas de emergência")
+		return
+	}
+	if result.Expired == 0 && result.ApprovalsTimedOut == 0 && result.Failed == 0 {
+		return
+	}
+
+	log.Info().
+		Int("expired", result.Expired).
+		Int("approvals_timed_out", result.ApprovalsTimedOut).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Desativações automáticas das contas de emergência concluídas")
+}
@@ -0,0 +1,618 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das contas de emergência
+const (
+	DefaultEmergencyAccessCacheTTL       = 30 * time.Second
+	DefaultEmergencyActivationDuration   = time.Hour
+	DefaultMaxEmergencyActivationTime    = 4 * time.Hour
+	DefaultEmergencyApprovalTimeout      = time.Hour
+	DefaultEmergencySweepBatchSize       = 100
+	DefaultEmergencyActionsListLimit     = 100
+	MaxEmergencyActionsListLimit         = 1000
+	emergencyActionsVerificationPageSize = 500
+)
+
+// EmergencyAccessConfig configura as contas de emergência
+type EmergencyAccessConfig struct {
+	// Tempo durante o qual as contas e as ativações em vigor de um tenant ficam em cache;
+	// as alterações feitas nesta instância invalidam o cache de imediato, e uma ativação
+	// terminada noutra instância deixa de gravar ações, pelo que os pedidos são recusados
+	CacheTTL time.Duration
+	// Duração das ativações sem duração indicada e duração máxima aceite
+	DefaultActivationDuration time.Duration
+	MaxActivationDuration     time.Duration
+	// Prazo para aprovar um pedido de ativação; depois é recusado automaticamente
+	ApprovalTimeout time.Duration
+	// Ativações processadas por lote nas desativações automáticas
+	SweepBatchSize int
+}
+
+// DefaultEmergencyAccessConfig retorna a configuração padrão das contas de emergência
+func DefaultEmergencyAccessConfig() EmergencyAccessConfig {
+	return EmergencyAccessConfig{
+		CacheTTL:                  DefaultEmergencyAccessCacheTTL,
+		DefaultActivationDuration: DefaultEmergencyActivationDuration,
+		MaxActivationDuration:     DefaultMaxEmergencyActivationTime,
+		ApprovalTimeout:           DefaultEmergencyApprovalTimeout,
+		SweepBatchSize:            DefaultEmergencySweepBatchSize,
+	}
+}
+
+// emergencyAccessSnapshot guarda em cache as contas de emergência de um tenant, indexadas pelo
+// usuário, e as ativações em vigor, indexadas pela conta
+type emergencyAccessSnapshot struct {
+	accounts    map[uuid.UUID]*model.EmergencyAccount
+	activations map[uuid.UUID]*model.EmergencyActivation
+	expiresAt   time.Time
+}
+
+// EmergencyAccessServiceImpl implementa a interface EmergencyAccessService
+type EmergencyAccessServiceImpl struct {
+	repository repository.EmergencyAccessRepository
+	config     EmergencyAccessConfig
+	now        func() time.Time
+
+	mutex     sync.Mutex
+	snapshots map[uuid.UUID]*emergencyAccessSnapshot
+}
+
+// NewEmergencyAccessService cria uma nova instância de EmergencyAccessService
+// Os valores fora do intervalo válido usam os padrões
+func NewEmergencyAccessService(repo repository.EmergencyAccessRepository, config EmergencyAccessConfig) application.EmergencyAccessService {
+	defaults := DefaultEmergencyAccessConfig()
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaults.CacheTTL
+	}
+	if config.MaxActivationDuration <= 0 {
+		config.MaxActivationDuration = defaults.MaxActivationDuration
+	}
+	if config.DefaultActivationDuration <= 0 || config.DefaultActivationDuration > config.MaxActivationDuration {
+		config.DefaultActivationDuration = minDuration(defaults.DefaultActivationDuration, config.MaxActivationDuration)
+	}
+	if config.ApprovalTimeout <= 0 {
+		config.ApprovalTimeout = defaults.ApprovalTimeout
+	}
+	if config.SweepBatchSize <= 0 {
+		config.SweepBatchSize = defaults.SweepBatchSize
+	}
+
+	return &EmergencyAccessServiceImpl{
+		repository: repo,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+		snapshots:  make(map[uuid.UUID]*emergencyAccessSnapshot),
+	}
+}
+
+// ProvisionAccount regista um usuário do tenant como conta de emergência selada
+func (s *EmergencyAccessServiceImpl) ProvisionAccount(ctx context.Context, req *application.ProvisionEmergencyAccountRequest) (*model.EmergencyAccount, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.ProvisionAccount", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+	))
+	defer span.End()
+
+	account := &model.EmergencyAccount{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		UserID:      req.UserID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   req.ActorID,
+		CreatedAt:   s.now(),
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateAccount(ctx, account); err != nil {
+		if errors.Is(err, model.ErrEmergencyAccountConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar conta de emergência: %w", err)
+	}
+	s.invalidate(account.TenantID)
+
+	log.Info().
+		Str("tenant_id", account.TenantID.String()).
+		Str("account_id", account.ID.String()).
+		Str("user_id", account.UserID.String()).
+		Str("actor_id", account.CreatedBy.String()).
+		Msg("Conta de emergência provisionada e selada")
+	return account, nil
+}
+
+// ListAccounts recupera as contas de emergência do tenant
+func (s *EmergencyAccessServiceImpl) ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]*model.EmergencyAccount, error) {
+	accounts, err := s.repository.ListAccounts(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar contas de emergência: %w", err)
+	}
+	return accounts, nil
+}
+
+// RequestActivation pede a ativação de uma conta de emergência, que fica pendente de aprovação
+func (s *EmergencyAccessServiceImpl) RequestActivation(ctx context.Context, req *application.RequestEmergencyActivationRequest) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.RequestActivation", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("account_id", req.AccountID.String()),
+		attribute.String("requested_by", req.RequestedBy.String()),
+	))
+	defer span.End()
+
+	duration := req.Duration
+	if duration == 0 {
+		duration = s.config.DefaultActivationDuration
+	}
+	if duration < 0 || duration > s.config.MaxActivationDuration {
+		return nil, fmt.Errorf("%w: a duração deve estar entre 0 e %s",
+			model.ErrInvalidEmergencyActivation, s.config.MaxActivationDuration)
+	}
+
+	account, err := s.repository.GetAccount(ctx, req.TenantID, req.AccountID)
+	if err != nil {
+		if errors.Is(err, model.ErrEmergencyAccountNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter conta de emergência: %w", err)
+	}
+
+	activation := &model.EmergencyActivation{
+		ID:              uuid.New(),
+		TenantID:        account.TenantID,
+		AccountID:       account.ID,
+		UserID:          account.UserID,
+		IncidentRef:     strings.TrimSpace(req.IncidentRef),
+		Justification:   strings.TrimSpace(req.Justification),
+		DurationSeconds: int(duration / time.Second),
+		Status:          model.EmergencyActivationPending,
+		RequestedBy:     req.RequestedBy,
+		RequestedAt:     s.now(),
+	}
+	if err := activation.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateActivation(ctx, activation); err != nil {
+		if errors.Is(err, model.ErrEmergencyActivationOpen) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar pedido de ativação: %w", err)
+	}
+
+	s.logActivation(activation, "Ativação de conta de emergência pedida")
+	return activation, nil
+}
+
+// ApproveActivation aprova um pedido de ativação; o aprovador tem de ser diferente do requerente
+func (s *EmergencyAccessServiceImpl) ApproveActivation(ctx context.Context, tenantID, activationID, approverID uuid.UUID) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.ApproveActivation", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("activation_id", activationID.String()),
+		attribute.String("approver_id", approverID.String()),
+	))
+	defer span.End()
+
+	return s.transition(ctx, tenantID, activationID, "Ativação de conta de emergência aprovada",
+		func(activation *model.EmergencyActivation, now time.Time) error {
+			if activation.RequestedAt.Add(s.config.ApprovalTimeout).Before(now) {
+				return fmt.Errorf("%w: o prazo de aprovação do pedido terminou", model.ErrEmergencyActivationState)
+			}
+			return activation.Approve(approverID, now)
+		})
+}
+
+// RejectActivation recusa um pedido de ativação pendente
+func (s *EmergencyAccessServiceImpl) RejectActivation(ctx context.Context, tenantID, activationID, actorID uuid.UUID, note string) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.RejectActivation", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("activation_id", activationID.String()),
+	))
+	defer span.End()
+
+	return s.transition(ctx, tenantID, activationID, "Ativação de conta de emergência recusada",
+		func(activation *model.EmergencyActivation, now time.Time) error {
+			return activation.Reject(&actorID, strings.TrimSpace(note), now)
+		})
+}
+
+// CloseActivation termina uma ativação antes de expirar
+func (s *EmergencyAccessServiceImpl) CloseActivation(ctx context.Context, tenantID, activationID, actorID uuid.UUID, reason string) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.CloseActivation", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("activation_id", activationID.String()),
+	))
+	defer span.End()
+
+	return s.transition(ctx, tenantID, activationID, "Ativação de conta de emergência terminada; revisão pendente",
+		func(activation *model.EmergencyActivation, now time.Time) error {
+			return activation.End(&actorID, strings.TrimSpace(reason), now)
+		})
+}
+
+// ReviewActivation regista a revisão de uma ativação terminada
+func (s *EmergencyAccessServiceImpl) ReviewActivation(ctx context.Context, req *application.ReviewEmergencyActivationRequest) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.ReviewActivation", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("activation_id", req.ActivationID.String()),
+		attribute.String("outcome", string(req.Outcome)),
+	))
+	defer span.End()
+
+	activation, err := s.transition(ctx, req.TenantID, req.ActivationID, "Ativação de conta de emergência revista",
+		func(activation *model.EmergencyActivation, now time.Time) error {
+			return activation.Review(req.ReviewerID, req.Outcome, strings.TrimSpace(req.Notes), now)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if activation.ReviewOutcome == model.EmergencyReviewUnjustified {
+		log.Warn().
+			Str("tenant_id", activation.TenantID.String()).
+			Str("activation_id", activation.ID.String()).
+			Str("incident_ref", activation.IncidentRef).
+			Str("requested_by", activation.RequestedBy.String()).
+			Msg("Uso de conta de emergência considerado injustificado na revisão")
+	}
+	return activation, nil
+}
+
+// GetActivation recupera uma ativação do tenant
+func (s *EmergencyAccessServiceImpl) GetActivation(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyActivation, error) {
+	activation, err := s.repository.GetActivation(ctx, tenantID, activationID)
+	if err != nil {
+		if errors.Is(err, model.ErrEmergencyActivationNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter ativação da conta de emergência: %w", err)
+	}
+	return activation, nil
+}
+
+// ListActivations recupera as ativações do tenant; status limita a um estado
+func (s *EmergencyAccessServiceImpl) ListActivations(ctx context.Context, tenantID uuid.UUID, status model.EmergencyActivationStatus) ([]*model.EmergencyActivation, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: estado desconhecido %q", model.ErrInvalidEmergencyActivation, status)
+	}
+	activations, err := s.repository.ListActivations(ctx, tenantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar ativações das contas de emergência: %w", err)
+	}
+	return activations, nil
+}
+
+// ListActions recupera as ações gravadas durante uma ativação, a seguir a afterSequence
+func (s *EmergencyAccessServiceImpl) ListActions(ctx context.Context, tenantID, activationID uuid.UUID, afterSequence int64, limit int) ([]*model.EmergencyAction, error) {
+	if limit <= 0 {
+		limit = DefaultEmergencyActionsListLimit
+	}
+	if limit > MaxEmergencyActionsListLimit {
+		limit = MaxEmergencyActionsListLimit
+	}
+	if afterSequence < 0 {
+		afterSequence = 0
+	}
+	if _, err := s.GetActivation(ctx, tenantID, activationID); err != nil {
+		return nil, err
+	}
+
+	actions, err := s.repository.ListActions(ctx, tenantID, activationID, afterSequence, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar ações da conta de emergência: %w", err)
+	}
+	return actions, nil
+}
+
+// VerifyActions percorre o registo completo da ativação e recalcula a cadeia de hashes
+func (s *EmergencyAccessServiceImpl) VerifyActions(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyChainVerification, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.VerifyActions", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("activation_id", activationID.String()),
+	))
+	defer span.End()
+
+	if _, err := s.GetActivation(ctx, tenantID, activationID); err != nil {
+		return nil, err
+	}
+
+	result := &model.EmergencyChainVerification{ActivationID: activationID, Valid: true}
+	var previous *model.EmergencyAction
+	for {
+		var after int64
+		if previous != nil {
+			after = previous.Sequence
+		}
+		actions, err := s.repository.ListActions(ctx, tenantID, activationID, after, emergencyActionsVerificationPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler ações da conta de emergência: %w", err)
+		}
+
+		if broken := model.VerifyEmergencyActionChain(previous, actions); broken != 0 {
+			result.Valid = false
+			result.BrokenAtSequence = broken
+			for _, action := range actions {
+				if action.Sequence < broken {
+					result.Actions++
+				}
+			}
+			break
+		}
+		result.Actions += len(actions)
+		if len(actions) > 0 {
+			previous = actions[len(actions)-1]
+		}
+		if len(actions) < emergencyActionsVerificationPageSize {
+			break
+		}
+	}
+	if previous != nil {
+		result.HeadHash = previous.Hash
+	}
+	result.VerifiedAt = s.now()
+
+	span.SetAttributes(
+		attribute.Int("actions", result.Actions),
+		attribute.Bool("valid", result.Valid),
+	)
+	if !result.Valid {
+		log.Error().
+			Str("tenant_id", tenantID.String()).
+			Str("activation_id", activationID.String()).
+			Int64("broken_at_sequence", result.BrokenAtSequence).
+			Msg("Registo das ações da conta de emergência adulterado")
+	}
+	return result, nil
+}
+
+// Authorize verifica um pedido autenticado contra as contas de emergência do tenant
+//
+// Os usuários que não são contas de emergência não são afetados. Os pedidos de uma conta de
+// emergência só são permitidos durante uma ativação em vigor, e só depois de ficarem gravados no
+// registo encadeado da ativação; sem registo o pedido é recusado.
+func (s *EmergencyAccessServiceImpl) Authorize(ctx context.Context, req *model.EmergencyAccessRequest) (*model.EmergencyAccessDecision, error) {
+	now := s.now()
+	snapshot, err := s.snapshot(ctx, req.TenantID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	account, ok := snapshot.accounts[req.UserID]
+	if !ok {
+		return &model.EmergencyAccessDecision{Allowed: true}, nil
+	}
+
+	activation, ok := snapshot.activations[account.ID]
+	if !ok || !activation.IsActive(now) {
+		return &model.EmergencyAccessDecision{
+			EmergencyAccount: true,
+			Reason:           model.EmergencyDenyReasonSealed,
+		}, nil
+	}
+
+	return s.recordAction(ctx, activation, req, now), nil
+}
+
+// recordAction grava o pedido no registo da ativação e permite-o
+// Se a ativação já terminou noutra instância o pedido é recusado como selado; se o registo
+// falhar, é recusado
+func (s *EmergencyAccessServiceImpl) recordAction(ctx context.Context, activation *model.EmergencyActivation, req *model.EmergencyAccessRequest, now time.Time) *model.EmergencyAccessDecision {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.recordAction", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("activation_id", activation.ID.String()),
+	))
+	defer span.End()
+
+	action := &model.EmergencyAction{
+		ID:           uuid.New(),
+		TenantID:     req.TenantID,
+		ActivationID: activation.ID,
+		UserID:       req.UserID,
+		Method:       req.Method,
+		Path:         req.Path,
+		Query:        req.Query,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+		RequestID:    req.RequestID,
+		OccurredAt:   now,
+	}
+	if err := s.repository.AppendAction(ctx, action); err != nil {
+		span.RecordError(err)
+		if errors.Is(err, model.ErrEmergencyActivationState) {
+			s.invalidate(req.TenantID)
+			return &model.EmergencyAccessDecision{EmergencyAccount: true, Reason: model.EmergencyDenyReasonSealed}
+		}
+		log.Error().Err(fmt.Errorf("%w: %v", model.ErrEmergencyRecordingFailure, err)).
+			Str("tenant_id", req.TenantID.String()).
+			Str("activation_id", activation.ID.String()).
+			Str("path", req.Path).
+			Msg("Pedido da conta de emergência recusado: falha ao gravar a ação")
+		return &model.EmergencyAccessDecision{EmergencyAccount: true, Reason: model.EmergencyDenyReasonRecordingFailed}
+	}
+
+	activationID := activation.ID
+	return &model.EmergencyAccessDecision{
+		Allowed:          true,
+		EmergencyAccount: true,
+		ActivationID:     &activationID,
+		ExpiresAt:        activation.ExpiresAt,
+	}
+}
+
+// RunScheduledDeactivations termina as ativações expiradas e recusa os pedidos não aprovados no prazo
+func (s *EmergencyAccessServiceImpl) RunScheduledDeactivations(ctx context.Context) (*application.EmergencyAccessSweepResult, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessServiceImpl.RunScheduledDeactivations")
+	defer span.End()
+
+	result := &application.EmergencyAccessSweepResult{StartedAt: s.now()}
+	pendingBefore := result.StartedAt.Add(-s.config.ApprovalTimeout)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		activations, err := s.repository.ListDueActivations(ctx, result.StartedAt, pendingBefore, s.config.SweepBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar ativações a terminar: %w", err)
+		}
+
+		failed := 0
+		for _, activation := range activations {
+			from := activation.Status
+			switch from {
+			case model.EmergencyActivationActive:
+				err = activation.End(nil, model.EmergencyEndReasonExpired, result.StartedAt)
+			case model.EmergencyActivationPending:
+				err = activation.Reject(nil, model.EmergencyEndReasonApprovalTimeout, result.StartedAt)
+			default:
+				err = model.ErrEmergencyActivationState
+			}
+			if err == nil {
+				err = s.repository.UpdateActivation(ctx, activation, from)
+			}
+			if err != nil {
+				failed++
+				log.Error().Err(err).
+					Str("tenant_id", activation.TenantID.String()).
+					Str("activation_id", activation.ID.String()).
+					Msg("Erro ao terminar ativação de conta de emergência")
+				continue
+			}
+
+			s.invalidate(activation.TenantID)
+			if from == model.EmergencyActivationActive {
+				result.Expired++
+				s.logActivation(activation, "Ativação de conta de emergência expirada; revisão pendente")
+			} else {
+				result.ApprovalsTimedOut++
+				s.logActivation(activation, "Pedido de ativação de conta de emergência recusado por falta de aprovação")
+			}
+		}
+		result.Failed += failed
+
+		// Parar quando não há mais ativações ou quando um lote inteiro falhou, para não repetir
+		// indefinidamente as mesmas
+		if len(activations) < s.config.SweepBatchSize || failed == len(activations) {
+			break
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("expired", result.Expired),
+		attribute.Int("approvals_timed_out", result.ApprovalsTimedOut),
+		attribute.Int("failed", result.Failed),
+	)
+	return result, nil
+}
+
+// transition carrega a ativação, aplica a transição e grava-a se o estado não foi alterado entretanto
+func (s *EmergencyAccessServiceImpl) transition(
+	ctx context.Context,
+	tenantID, activationID uuid.UUID,
+	message string,
+	apply func(activation *model.EmergencyActivation, now time.Time) error,
+) (*model.EmergencyActivation, error) {
+	activation, err := s.GetActivation(ctx, tenantID, activationID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := activation.Status
+	if err := apply(activation, s.now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateActivation(ctx, activation, from); err != nil {
+		if errors.Is(err, model.ErrEmergencyActivationState) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar ativação da conta de emergência: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	s.logActivation(activation, message)
+	return activation, nil
+}
+
+// snapshot retorna as contas e as ativações em vigor do tenant, carregando-as quando o cache expirou
+func (s *EmergencyAccessServiceImpl) snapshot(ctx context.Context, tenantID uuid.UUID, now time.Time) (*emergencyAccessSnapshot, error) {
+	s.mutex.Lock()
+	snapshot, ok := s.snapshots[tenantID]
+	s.mutex.Unlock()
+	if ok && now.Before(snapshot.expiresAt) {
+		return snapshot, nil
+	}
+
+	accounts, err := s.repository.ListAccounts(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar contas de emergência: %w", err)
+	}
+	snapshot = &emergencyAccessSnapshot{
+		accounts:    make(map[uuid.UUID]*model.EmergencyAccount, len(accounts)),
+		activations: make(map[uuid.UUID]*model.EmergencyActivation),
+		expiresAt:   now.Add(s.config.CacheTTL),
+	}
+	for _, account := range accounts {
+		snapshot.accounts[account.UserID] = account
+	}
+
+	// Os tenants sem contas de emergência não precisam das ativações
+	if len(accounts) > 0 {
+		activations, err := s.repository.ListActivations(ctx, tenantID, model.EmergencyActivationActive)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar ativações das contas de emergência: %w", err)
+		}
+		for _, activation := range activations {
+			snapshot.activations[activation.AccountID] = activation
+		}
+	}
+
+	s.mutex.Lock()
+	s.snapshots[tenantID] = snapshot
+	s.mutex.Unlock()
+	return snapshot, nil
+}
+
+// invalidate descarta o cache do tenant após uma alteração
+func (s *EmergencyAccessServiceImpl) invalidate(tenantID uuid.UUID) {
+	s.mutex.Lock()
+	delete(s.snapshots, tenantID)
+	s.mutex.Unlock()
+}
+
+// logActivation regista uma alteração de uma ativação
+func (s *EmergencyAccessServiceImpl) logActivation(activation *model.EmergencyActivation, message string) {
+	event := log.Warn().
+		Str("tenant_id", activation.TenantID.String()).
+		Str("activation_id", activation.ID.String()).
+		Str("account_id", activation.AccountID.String()).
+		Str("incident_ref", activation.IncidentRef).
+		Str("status", string(activation.Status)).
+		Str("requested_by", activation.RequestedBy.String())
+	if activation.DecidedBy != nil {
+		event = event.Str("decided_by", activation.DecidedBy.String())
+	}
+	if activation.ExpiresAt != nil {
+		event = event.Time("expires_at", *activation.ExpiresAt)
+	}
+	event.Msg(message)
+}
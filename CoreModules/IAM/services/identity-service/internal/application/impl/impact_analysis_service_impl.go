@@ -0,0 +1,475 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Tamanho de página usado para percorrer listas completas durante a análise
+const impactAnalysisPageSize = 100
+
+// Chave de metadados da permissão com os escopos de API que ela concede
+const permissionScopesMetadataKey = "api_scopes"
+
+// ImpactAnalysisServiceImpl implementa a interface ImpactAnalysisService
+type ImpactAnalysisServiceImpl struct {
+	roleService application.RoleService
+}
+
+// NewImpactAnalysisService cria uma nova instância de ImpactAnalysisService
+func NewImpactAnalysisService(roleService application.RoleService) application.ImpactAnalysisService {
+	return &ImpactAnalysisServiceImpl{
+		roleService: roleService,
+	}
+}
+
+// roleNode representa uma função no grafo de hierarquia carregado para a análise
+type roleNode struct {
+	role        *model.Role
+	parents     []uuid.UUID
+	permissions map[uuid.UUID]*model.Permission
+}
+
+// impactGraph mantém as funções carregadas e aplica a alteração proposta em memória
+type impactGraph struct {
+	ctx      context.Context
+	service  application.RoleService
+	tenantID uuid.UUID
+	req      application.ImpactAnalysisRequest
+	nodes    map[uuid.UUID]*roleNode
+	before   map[uuid.UUID]map[uuid.UUID]*model.Permission
+	after    map[uuid.UUID]map[uuid.UUID]*model.Permission
+}
+
+// AnalyzeImpact calcula o impacto de uma alteração proposta sem aplicá-la
+func (s *ImpactAnalysisServiceImpl) AnalyzeImpact(ctx context.Context, req application.ImpactAnalysisRequest) (*application.ImpactReport, error) {
+	ctx, span := tracer.Start(ctx, "ImpactAnalysisServiceImpl.AnalyzeImpact", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("role_id", req.RoleID.String()),
+		attribute.String("mutation", string(req.Mutation)),
+	))
+	defer span.End()
+
+	if err := validateImpactRequest(req); err != nil {
+		return nil, err
+	}
+
+	graph := &impactGraph{
+		ctx:      ctx,
+		service:  s.roleService,
+		tenantID: req.TenantID,
+		req:      req,
+		nodes:    make(map[uuid.UUID]*roleNode),
+		before:   make(map[uuid.UUID]map[uuid.UUID]*model.Permission),
+		after:    make(map[uuid.UUID]map[uuid.UUID]*model.Permission),
+	}
+
+	target, err := graph.load(req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validar a alteração proposta contra o estado atual
+	switch req.Mutation {
+	case application.MutationRevokePermission:
+		if _, ok := target.permissions[*req.PermissionID]; !ok {
+			return nil, application.ErrPermissionNotAssigned
+		}
+	case application.MutationRemoveChildRole:
+		child, err := graph.load(*req.ChildRoleID)
+		if err != nil {
+			return nil, err
+		}
+		if !containsID(child.parents, req.RoleID) {
+			return nil, application.ErrChildRoleNotAssigned
+		}
+	}
+
+	// Funções candidatas: a raiz afetada pela alteração e todos os seus descendentes
+	rootID := req.RoleID
+	if req.Mutation == application.MutationRemoveChildRole {
+		rootID = *req.ChildRoleID
+	}
+
+	descendants, err := s.roleService.GetDescendantRoles(ctx, req.TenantID, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter funções descendentes: %w", err)
+	}
+
+	candidates := []uuid.UUID{rootID}
+	for _, d := range descendants {
+		candidates = append(candidates, d.ID)
+	}
+
+	report := &application.ImpactReport{
+		TenantID:       req.TenantID,
+		Mutation:       req.Mutation,
+		TargetRoleID:   target.role.ID,
+		TargetRoleCode: target.role.Code,
+		PermissionID:   req.PermissionID,
+		ChildRoleID:    req.ChildRoleID,
+		AffectedRoles:  []application.RoleImpact{},
+		AffectedUsers:  []application.UserImpact{},
+		BrokenScopes:   []application.ScopeImpact{},
+		AnalyzedAt:     time.Now().UTC(),
+	}
+
+	// Calcular permissões perdidas por função
+	removedPermissions := make(map[string]bool)
+	for _, roleID := range candidates {
+		lost, err := graph.lostPermissions(roleID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Funções removidas perdem todas as permissões efetivas
+		isTarget := roleID == req.RoleID
+		if len(lost) == 0 && !isTarget {
+			continue
+		}
+
+		node := graph.nodes[roleID]
+		codes := permissionCodes(lost)
+		for _, code := range codes {
+			removedPermissions[code] = true
+		}
+
+		report.AffectedRoles = append(report.AffectedRoles, application.RoleImpact{
+			RoleID:          roleID,
+			RoleCode:        node.role.Code,
+			RoleName:        node.role.Name,
+			IsTarget:        isTarget,
+			LostPermissions: codes,
+		})
+	}
+
+	// Calcular usuários afetados, considerando acessos mantidos por outras funções
+	userRoles := make(map[uuid.UUID][]string)
+	var userOrder []uuid.UUID
+	for _, impact := range report.AffectedRoles {
+		users, err := s.allRoleUsers(ctx, req.TenantID, impact.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if _, seen := userRoles[u.UserID]; !seen {
+				userOrder = append(userOrder, u.UserID)
+			}
+			userRoles[u.UserID] = append(userRoles[u.UserID], impact.RoleCode)
+		}
+	}
+
+	scopeUsers := make(map[string]map[uuid.UUID]bool)
+	scopePermissions := make(map[string]map[string]bool)
+	for _, userID := range userOrder {
+		assignments, err := s.roleService.GetUserActiveRoles(ctx, req.TenantID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter funções ativas do usuário: %w", err)
+		}
+
+		beforePerms := make(map[uuid.UUID]*model.Permission)
+		afterPerms := make(map[uuid.UUID]*model.Permission)
+		for _, assignment := range assignments {
+			if assignment.Role == nil {
+				continue
+			}
+			b, err := graph.effective(assignment.Role.ID, false)
+			if err != nil {
+				return nil, err
+			}
+			a, err := graph.effective(assignment.Role.ID, true)
+			if err != nil {
+				return nil, err
+			}
+			mergePermissions(beforePerms, b)
+			mergePermissions(afterPerms, a)
+		}
+
+		lost := subtractPermissions(beforePerms, afterPerms)
+		if len(lost) == 0 {
+			report.Summary.UsersRetainingAccess++
+			continue
+		}
+
+		lostScopes := make(map[string]bool)
+		for _, p := range lost {
+			for _, scope := range permissionScopes(p) {
+				lostScopes[scope] = true
+				if scopeUsers[scope] == nil {
+					scopeUsers[scope] = make(map[uuid.UUID]bool)
+					scopePermissions[scope] = make(map[string]bool)
+				}
+				scopeUsers[scope][userID] = true
+				scopePermissions[scope][p.Code] = true
+			}
+		}
+
+		viaRoles := userRoles[userID]
+		sort.Strings(viaRoles)
+		report.AffectedUsers = append(report.AffectedUsers, application.UserImpact{
+			UserID:          userID,
+			ViaRoles:        viaRoles,
+			LostPermissions: permissionCodes(lost),
+			LostScopes:      sortedKeys(lostScopes),
+		})
+	}
+
+	for _, scope := range sortedKeys(toStringSet(scopeUsers)) {
+		report.BrokenScopes = append(report.BrokenScopes, application.ScopeImpact{
+			Scope:         scope,
+			Permissions:   sortedKeys(scopePermissions[scope]),
+			AffectedUsers: len(scopeUsers[scope]),
+		})
+	}
+
+	report.Summary.AffectedRoles = len(report.AffectedRoles)
+	report.Summary.AffectedUsers = len(report.AffectedUsers)
+	report.Summary.BrokenScopes = len(report.BrokenScopes)
+	report.Summary.PermissionsRemoved = len(removedPermissions)
+
+	span.SetAttributes(
+		attribute.Int("impact.affected_roles", report.Summary.AffectedRoles),
+		attribute.Int("impact.affected_users", report.Summary.AffectedUsers),
+		attribute.Int("impact.broken_scopes", report.Summary.BrokenScopes),
+	)
+
+	return report, nil
+}
+
+// allRoleUsers percorre todas as páginas de usuários ativos de uma função
+func (s *ImpactAnalysisServiceImpl) allRoleUsers(ctx context.Context, tenantID, roleID uuid.UUID) ([]application.UserRoleDetail, error) {
+	var result []application.UserRoleDetail
+	for page := 1; ; page++ {
+		users, total, err := s.roleService.GetRoleUsers(ctx, tenantID, roleID, true, application.Pagination{Page: page, PageSize: impactAnalysisPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter usuários da função: %w", err)
+		}
+		result = append(result, users...)
+		if len(users) == 0 || int64(len(result)) >= total {
+			return result, nil
+		}
+	}
+}
+
+// load carrega uma função, as suas permissões diretas e os seus pais
+func (g *impactGraph) load(roleID uuid.UUID) (*roleNode, error) {
+	if node, ok := g.nodes[roleID]; ok {
+		return node, nil
+	}
+
+	role, err := g.service.GetRole(g.ctx, g.tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &roleNode{
+		role:        role,
+		permissions: make(map[uuid.UUID]*model.Permission),
+	}
+
+	for page := 1; ; page++ {
+		permissions, total, err := g.service.GetRolePermissions(g.ctx, g.tenantID, roleID, application.Pagination{Page: page, PageSize: impactAnalysisPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter permissões da função: %w", err)
+		}
+		for _, p := range permissions {
+			node.permissions[p.ID] = p
+		}
+		if len(permissions) == 0 || int64(len(node.permissions)) >= total {
+			break
+		}
+	}
+
+	for page := 1; ; page++ {
+		parents, total, err := g.service.GetParentRoles(g.ctx, g.tenantID, roleID, application.Pagination{Page: page, PageSize: impactAnalysisPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter funções pai: %w", err)
+		}
+		for _, p := range parents {
+			node.parents = append(node.parents, p.ID)
+		}
+		if len(parents) == 0 || int64(len(node.parents)) >= total {
+			break
+		}
+	}
+
+	g.nodes[roleID] = node
+	return node, nil
+}
+
+// effective calcula as permissões efetivas de uma função, antes ou depois da alteração
+func (g *impactGraph) effective(roleID uuid.UUID, applyMutation bool) (map[uuid.UUID]*model.Permission, error) {
+	cache := g.before
+	if applyMutation {
+		cache = g.after
+	}
+	if perms, ok := cache[roleID]; ok {
+		return perms, nil
+	}
+
+	// Marcar antes da recursão para interromper ciclos na hierarquia
+	perms := make(map[uuid.UUID]*model.Permission)
+	cache[roleID] = perms
+
+	node, err := g.load(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.role.IsActive {
+		return perms, nil
+	}
+
+	removed := applyMutation && roleID == g.req.RoleID &&
+		(g.req.Mutation == application.MutationDeactivateRole || g.req.Mutation == application.MutationDeleteRole)
+	if removed {
+		return perms, nil
+	}
+
+	for id, p := range node.permissions {
+		if applyMutation && g.req.Mutation == application.MutationRevokePermission &&
+			roleID == g.req.RoleID && id == *g.req.PermissionID {
+			continue
+		}
+		perms[id] = p
+	}
+
+	for _, parentID := range node.parents {
+		if applyMutation && g.req.Mutation == application.MutationRemoveChildRole &&
+			roleID == *g.req.ChildRoleID && parentID == g.req.RoleID {
+			continue
+		}
+		inherited, err := g.effective(parentID, applyMutation)
+		if err != nil {
+			return nil, err
+		}
+		mergePermissions(perms, inherited)
+	}
+
+	return perms, nil
+}
+
+// lostPermissions retorna as permissões efetivas que a função perderia
+func (g *impactGraph) lostPermissions(roleID uuid.UUID) ([]*model.Permission, error) {
+	before, err := g.effective(roleID, false)
+	if err != nil {
+		return nil, err
+	}
+	after, err := g.effective(roleID, true)
+	if err != nil {
+		return nil, err
+	}
+	return subtractPermissions(before, after), nil
+}
+
+// validateImpactRequest valida os campos obrigatórios de cada tipo de alteração
+func validateImpactRequest(req application.ImpactAnalysisRequest) error {
+	if req.RoleID == uuid.Nil {
+		return fmt.Errorf("%w: ID da função é obrigatório", application.ErrInvalidMutation)
+	}
+
+	switch req.Mutation {
+	case application.MutationRevokePermission:
+		if req.PermissionID == nil {
+			return fmt.Errorf("%w: ID da permissão é obrigatório", application.ErrInvalidMutation)
+		}
+	case application.MutationRemoveChildRole:
+		if req.ChildRoleID == nil {
+			return fmt.Errorf("%w: ID da função filha é obrigatório", application.ErrInvalidMutation)
+		}
+	case application.MutationDeactivateRole, application.MutationDeleteRole:
+	default:
+		return fmt.Errorf("%w: tipo desconhecido '%s'", application.ErrInvalidMutation, req.Mutation)
+	}
+
+	return nil
+}
+
+// permissionScopes retorna os escopos de API concedidos por uma permissão
+func permissionScopes(p *model.Permission) []string {
+	if raw, ok := p.Metadata[permissionScopesMetadataKey]; ok {
+		var scopes []string
+		switch v := raw.(type) {
+		case []string:
+			scopes = v
+		case []interface{}:
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+		}
+		if len(scopes) > 0 {
+			return scopes
+		}
+	}
+
+	// Sem escopos explícitos, o código da permissão é usado como escopo
+	return []string{p.Code}
+}
+
+// mergePermissions adiciona as permissões de src em dst
+func mergePermissions(dst, src map[uuid.UUID]*model.Permission) {
+	for id, p := range src {
+		dst[id] = p
+	}
+}
+
+// subtractPermissions retorna as permissões de before ausentes em after
+func subtractPermissions(before, after map[uuid.UUID]*model.Permission) []*model.Permission {
+	var lost []*model.Permission
+	for id, p := range before {
+		if _, ok := after[id]; !ok {
+			lost = append(lost, p)
+		}
+	}
+	return lost
+}
+
+// permissionCodes retorna os códigos ordenados de uma lista de permissões
+func permissionCodes(permissions []*model.Permission) []string {
+	codes := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		codes = append(codes, p.Code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// sortedKeys retorna as chaves ordenadas de um conjunto de strings
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toStringSet converte as chaves de um mapa em conjunto
+func toStringSet(m map[string]map[uuid.UUID]bool) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}
+
+// containsID verifica se um ID está presente na lista
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da aceitação de documentos legais
+const (
+	DefaultLegalAcceptancesLimit = 100
+	MaxLegalAcceptancesLimit     = 1000
+	// Tamanho máximo do número da versão e do título de um documento
+	maxLegalDocumentVersionLength = 50
+	maxLegalDocumentTitleLength   = 255
+)
+
+// LegalConsentConfig configura a aceitação de documentos legais
+type LegalConsentConfig struct {
+	// BlockLogin impede a conclusão do login enquanto houver documentos obrigatórios por aceitar
+	BlockLogin bool
+}
+
+// DefaultLegalConsentConfig retorna a configuração padrão da aceitação de documentos legais
+func DefaultLegalConsentConfig() LegalConsentConfig {
+	return LegalConsentConfig{BlockLogin: true}
+}
+
+// LegalConsentServiceImpl implementa a interface LegalConsentService
+type LegalConsentServiceImpl struct {
+	repository repository.LegalConsentRepository
+	config     LegalConsentConfig
+	now        func() time.Time
+}
+
+// NewLegalConsentService cria uma nova instância de LegalConsentService
+func NewLegalConsentService(repo repository.LegalConsentRepository, config LegalConsentConfig) application.LegalConsentService {
+	return &LegalConsentServiceImpl{
+		repository: repo,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// LoginPendingDocuments retorna os documentos obrigatórios em vigor que o usuário ainda não aceitou
+// Com o bloqueio do login desativado, os documentos continuam por aceitar mas o login é concluído
+func (s *LegalConsentServiceImpl) LoginPendingDocuments(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LegalDocument, error) {
+	if !s.config.BlockLogin {
+		return nil, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.LoginPendingDocuments", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	documents, err := s.repository.ListUnacceptedDocuments(ctx, tenantID, userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar documentos legais por aceitar: %w", err)
+	}
+
+	var required []*model.LegalDocument
+	for _, document := range documents {
+		if document.Required {
+			required = append(required, document)
+		}
+	}
+	return required, nil
+}
+
+// PublishDocument publica uma nova versão de um documento legal do tenant
+// As aceitações de versões anteriores não valem para a nova versão, que passa a estar
+// pendente para todos os usuários quando entra em vigor
+func (s *LegalConsentServiceImpl) PublishDocument(ctx context.Context, req *application.PublishLegalDocumentRequest) (*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.PublishDocument", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("document_type", string(req.Type)),
+		attribute.String("document_version", req.Version),
+	))
+	defer span.End()
+
+	now := s.now()
+	document := &model.LegalDocument{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		Type:        req.Type,
+		Version:     strings.TrimSpace(req.Version),
+		Title:       strings.TrimSpace(req.Title),
+		URL:         strings.TrimSpace(req.URL),
+		ContentHash: strings.ToLower(strings.TrimSpace(req.ContentHash)),
+		Required:    req.Required,
+		EffectiveAt: now,
+		PublishedBy: req.PublishedBy,
+		CreatedAt:   now,
+	}
+	if req.EffectiveAt != nil {
+		document.EffectiveAt = req.EffectiveAt.UTC()
+	}
+	if err := validateLegalDocument(document); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateDocument(ctx, document); err != nil {
+		if errors.Is(err, model.ErrLegalDocumentVersionExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar documento legal: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", document.TenantID.String()).
+		Str("document_id", document.ID.String()).
+		Str("type", string(document.Type)).
+		Str("version", document.Version).
+		Bool("required", document.Required).
+		Time("effective_at", document.EffectiveAt).
+		Msg("Documento legal publicado")
+	return document, nil
+}
+
+// GetDocument recupera uma versão de um documento legal do tenant
+func (s *LegalConsentServiceImpl) GetDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.GetDocument", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("document_id", documentID.String()),
+	))
+	defer span.End()
+
+	document, err := s.repository.GetDocument(ctx, tenantID, documentID)
+	if err != nil {
+		if errors.Is(err, model.ErrLegalDocumentNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter documento legal: %w", err)
+	}
+	return document, nil
+}
+
+// ListDocuments recupera as versões publicadas pelo tenant ou, com currentOnly, as versões em vigor
+func (s *LegalConsentServiceImpl) ListDocuments(ctx context.Context, tenantID uuid.UUID, currentOnly bool) ([]*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.ListDocuments", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.Bool("current_only", currentOnly),
+	))
+	defer span.End()
+
+	var (
+		documents []*model.LegalDocument
+		err       error
+	)
+	if currentOnly {
+		documents, err = s.repository.ListCurrentDocuments(ctx, tenantID, s.now())
+	} else {
+		documents, err = s.repository.ListDocuments(ctx, tenantID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar documentos legais: %w", err)
+	}
+	if documents == nil {
+		documents = []*model.LegalDocument{}
+	}
+	return documents, nil
+}
+
+// ListPendingDocuments recupera as versões em vigor que o usuário ainda não aceitou
+func (s *LegalConsentServiceImpl) ListPendingDocuments(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.ListPendingDocuments", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	documents, err := s.repository.ListUnacceptedDocuments(ctx, tenantID, userID, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar documentos legais por aceitar: %w", err)
+	}
+	if documents == nil {
+		documents = []*model.LegalDocument{}
+	}
+	return documents, nil
+}
+
+// Accept regista a aceitação da versão em vigor de um documento pelo usuário
+// Só a versão em vigor pode ser aceite e o hash apresentado tem de corresponder ao publicado,
+// para que o registo prove exatamente o conteúdo aceite. Aceitar de novo retorna o registo existente
+func (s *LegalConsentServiceImpl) Accept(ctx context.Context, req *application.AcceptLegalDocumentRequest) (*model.LegalAcceptance, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.Accept", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("document_id", req.DocumentID.String()),
+	))
+	defer span.End()
+
+	document, err := s.GetDocument(ctx, req.TenantID, req.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(strings.TrimSpace(req.DocumentHash)) != document.ContentHash {
+		return nil, model.ErrLegalDocumentHashMismatch
+	}
+
+	now := s.now()
+	current, err := s.repository.ListCurrentDocuments(ctx, req.TenantID, now)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar documentos legais em vigor: %w", err)
+	}
+	isCurrent := false
+	for _, candidate := range current {
+		if candidate.ID == document.ID {
+			isCurrent = true
+			break
+		}
+	}
+	if !isCurrent {
+		return nil, model.ErrLegalDocumentNotCurrent
+	}
+
+	acceptance, err := s.repository.SaveAcceptance(ctx, &model.LegalAcceptance{
+		ID:           uuid.New(),
+		TenantID:     document.TenantID,
+		UserID:       req.UserID,
+		DocumentID:   document.ID,
+		DocumentType: document.Type,
+		Version:      document.Version,
+		DocumentHash: document.ContentHash,
+		AcceptedAt:   now,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gravar aceitação do documento legal: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", acceptance.TenantID.String()).
+		Str("user_id", acceptance.UserID.String()).
+		Str("document_id", acceptance.DocumentID.String()).
+		Str("type", string(acceptance.DocumentType)).
+		Str("version", acceptance.Version).
+		Msg("Documento legal aceite")
+	return acceptance, nil
+}
+
+// ListAcceptances recupera os registos de aceitação do tenant
+func (s *LegalConsentServiceImpl) ListAcceptances(ctx context.Context, tenantID uuid.UUID, filter model.LegalAcceptanceFilter) ([]*model.LegalAcceptance, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.ListAcceptances", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	if filter.Limit < 0 {
+		return nil, fmt.Errorf("%w: limite negativo", model.ErrInvalidLegalAcceptanceFilter)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultLegalAcceptancesLimit
+	}
+	if filter.Limit > MaxLegalAcceptancesLimit {
+		filter.Limit = MaxLegalAcceptancesLimit
+	}
+
+	acceptances, err := s.repository.ListAcceptances(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar registos de aceitação: %w", err)
+	}
+	if acceptances == nil {
+		acceptances = []*model.LegalAcceptance{}
+	}
+	return acceptances, nil
+}
+
+// AcceptanceReport resume a aceitação de uma versão de um documento pelos usuários ativos do tenant
+func (s *LegalConsentServiceImpl) AcceptanceReport(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalAcceptanceReport, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentServiceImpl.AcceptanceReport", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("document_id", documentID.String()),
+	))
+	defer span.End()
+
+	document, err := s.GetDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeUsers, accepted, err := s.repository.CountAcceptances(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar aceitações do documento legal: %w", err)
+	}
+
+	report := &model.LegalAcceptanceReport{
+		Document:    document,
+		ActiveUsers: activeUsers,
+		Accepted:    accepted,
+		Pending:     activeUsers - accepted,
+		GeneratedAt: s.now(),
+	}
+	if activeUsers > 0 {
+		report.AcceptanceRate = float64(accepted) / float64(activeUsers)
+	}
+	return report, nil
+}
+
+// validateLegalDocument verifica os campos de uma nova versão de um documento legal
+func validateLegalDocument(document *model.LegalDocument) error {
+	switch {
+	case document.TenantID == uuid.Nil:
+		return model.ErrInvalidTenantID
+	case !document.Type.IsValid():
+		return fmt.Errorf("%w: tipo %q desconhecido", model.ErrInvalidLegalDocument, document.Type)
+	case document.Version == "" || len(document.Version) > maxLegalDocumentVersionLength:
+		return fmt.Errorf("%w: versão obrigatória com até %d caracteres", model.ErrInvalidLegalDocument, maxLegalDocumentVersionLength)
+	case document.Title == "" || len(document.Title) > maxLegalDocumentTitleLength:
+		return fmt.Errorf("%w: título obrigatório com até %d caracteres", model.ErrInvalidLegalDocument, maxLegalDocumentTitleLength)
+	case !model.IsValidLegalDocumentHash(document.ContentHash):
+		return fmt.Errorf("%w: o hash do conteúdo tem de ser um SHA-256 hexadecimal", model.ErrInvalidLegalDocument)
+	}
+
+	parsed, err := url.Parse(document.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%w: URL do documento inválido", model.ErrInvalidLegalDocument)
+	}
+	return nil
+}
@@ -0,0 +1,665 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Valores padrão das notificações de login
+const (
+	DefaultLoginNotificationResponseTTL        = 7 * 24 * time.Hour
+	DefaultLoginNotificationCredentialResetTTL = time.Hour
+	DefaultLoginNotificationContextRetention   = 180 * 24 * time.Hour
+	DefaultLoginNotificationListLimit          = 50
+)
+
+// Tamanho mínimo da chave que protege os contextos conhecidos e os links "não fui eu"
+const MinLoginNotificationSigningKeySize = 32
+
+// Tamanho máximo do dispositivo apresentado nas mensagens
+const maxLoginNotificationDeviceSize = 120
+
+// LoginNotificationConfig configura a deteção dos logins suspeitos e as respostas "não fui eu"
+type LoginNotificationConfig struct {
+	// Chave HMAC dos contextos conhecidos e dos links "não fui eu"
+	SigningKey []byte
+	// Página que recebe a resposta "não fui eu"; o token é acrescentado no parâmetro token
+	ResponseURL string
+	// Página de redefinição da credencial; o token é acrescentado no parâmetro token
+	CredentialResetURL string
+	// Validade do link "não fui eu" e do link de redefinição
+	ResponseTTL        time.Duration
+	CredentialResetTTL time.Duration
+	// Tempo sem uso após o qual um dispositivo ou localização volta a ser desconhecido
+	ContextRetention time.Duration
+	// Número máximo de notificações retornadas por usuário
+	ListLimit int
+}
+
+// DefaultLoginNotificationConfig retorna a configuração padrão das notificações de login
+// A chave de assinatura e as páginas de resposta e de redefinição não têm valor padrão
+func DefaultLoginNotificationConfig() LoginNotificationConfig {
+	return LoginNotificationConfig{
+		ResponseTTL:        DefaultLoginNotificationResponseTTL,
+		CredentialResetTTL: DefaultLoginNotificationCredentialResetTTL,
+		ContextRetention:   DefaultLoginNotificationContextRetention,
+		ListLimit:          DefaultLoginNotificationListLimit,
+	}
+}
+
+// LoginNotificationServiceImpl implementa a interface LoginNotificationService
+type LoginNotificationServiceImpl struct {
+	repository repository.LoginNotificationRepository
+	senders    map[model.LoginNotificationChannel]application.LoginNotificationSender
+	config     LoginNotificationConfig
+	now        func() time.Time
+}
+
+// NewLoginNotificationService cria uma nova instância de LoginNotificationService
+// Os canais sem remetente são ignorados nas entregas. A chave de assinatura deve ter pelo menos
+// MinLoginNotificationSigningKeySize bytes; os restantes valores fora do intervalo válido usam os padrões
+func NewLoginNotificationService(
+	repo repository.LoginNotificationRepository,
+	senders map[model.LoginNotificationChannel]application.LoginNotificationSender,
+	config LoginNotificationConfig,
+) application.LoginNotificationService {
+	defaults := DefaultLoginNotificationConfig()
+	if config.ResponseTTL <= 0 {
+		config.ResponseTTL = defaults.ResponseTTL
+	}
+	if config.CredentialResetTTL <= 0 {
+		config.CredentialResetTTL = defaults.CredentialResetTTL
+	}
+	if config.ContextRetention <= 0 {
+		config.ContextRetention = defaults.ContextRetention
+	}
+	if config.ListLimit <= 0 {
+		config.ListLimit = defaults.ListLimit
+	}
+
+	return &LoginNotificationServiceImpl{
+		repository: repo,
+		senders:    senders,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GetSettings recupera a configuração das notificações do tenant
+// Os tenants que nunca a configuraram têm as notificações desativadas
+func (s *LoginNotificationServiceImpl) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.LoginNotificationSettings, error) {
+	settings, err := s.repository.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter configuração das notificações de login: %w", err)
+	}
+	if settings == nil {
+		return model.DefaultLoginNotificationSettings(tenantID), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings altera a configuração das notificações do tenant
+func (s *LoginNotificationServiceImpl) UpdateSettings(ctx context.Context, req *application.UpdateLoginNotificationSettingsRequest) (*model.LoginNotificationSettings, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationServiceImpl.UpdateSettings", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.Bool("enabled", req.Enabled),
+	))
+	defer span.End()
+
+	settings := &model.LoginNotificationSettings{
+		TenantID:         req.TenantID,
+		Enabled:          req.Enabled,
+		Channels:         req.Channels,
+		Market:           strings.ToLower(strings.TrimSpace(req.Market)),
+		DefaultLocale:    model.NormalizeLoginNotificationLocale(req.DefaultLocale),
+		NotifyFirstLogin: req.NotifyFirstLogin,
+		UpdatedBy:        req.UpdatedBy,
+		UpdatedAt:        s.now(),
+	}
+	if settings.DefaultLocale == "" {
+		// Mantém o valor recebido para que Validate rejeite um idioma inválido
+		settings.DefaultLocale = strings.TrimSpace(req.DefaultLocale)
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SaveSettings(ctx, settings); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar configuração das notificações de login: %w", err)
+	}
+
+	channels := make([]string, len(settings.Channels))
+	for i, channel := range settings.Channels {
+		channels[i] = string(channel)
+	}
+	log.Info().
+		Str("tenant_id", settings.TenantID.String()).
+		Bool("enabled", settings.Enabled).
+		Strs("channels", channels).
+		Str("market", settings.Market).
+		Str("updated_by", settings.UpdatedBy.String()).
+		Msg("Configuração das notificações de login atualizada")
+
+	return settings, nil
+}
+
+// ListTemplates recupera os modelos de mensagem personalizados do tenant
+func (s *LoginNotificationServiceImpl) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]*model.LoginNotificationTemplate, error) {
+	templates, err := s.repository.ListTemplates(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar modelos de notificação de login: %w", err)
+	}
+	return templates, nil
+}
+
+// SaveTemplate valida e grava um modelo de mensagem do tenant
+func (s *LoginNotificationServiceImpl) SaveTemplate(ctx context.Context, req *application.SaveLoginNotificationTemplateRequest) (*model.LoginNotificationTemplate, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationServiceImpl.SaveTemplate", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("kind", string(req.Kind)),
+		attribute.String("channel", string(req.Channel)),
+	))
+	defer span.End()
+
+	template := &model.LoginNotificationTemplate{
+		ID:        uuid.New(),
+		TenantID:  req.TenantID,
+		Kind:      req.Kind,
+		Channel:   req.Channel,
+		Market:    req.Market,
+		Locale:    req.Locale,
+		Subject:   strings.TrimSpace(req.Subject),
+		Body:      req.Body,
+		UpdatedBy: req.UpdatedBy,
+		UpdatedAt: s.now(),
+	}
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.ListTemplates(ctx, req.TenantID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao listar modelos de notificação de login: %w", err)
+	}
+	replaces := false
+	for _, current := range existing {
+		if current.Kind == template.Kind && current.Channel == template.Channel &&
+			current.Market == template.Market && current.Locale == template.Locale {
+			replaces = true
+			break
+		}
+	}
+	if !replaces && len(existing) >= model.MaxLoginNotificationTemplates {
+		return nil, fmt.Errorf("%w: máximo de %d modelos por tenant", model.ErrInvalidLoginNotificationTemplate, model.MaxLoginNotificationTemplates)
+	}
+
+	if err := s.repository.SaveTemplate(ctx, template); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar modelo de notificação de login: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", template.TenantID.String()).
+		Str("template_id", template.ID.String()).
+		Str("kind", string(template.Kind)).
+		Str("channel", string(template.Channel)).
+		Str("market", template.Market).
+		Str("locale", template.Locale).
+		Str("updated_by", template.UpdatedBy.String()).
+		Msg("Modelo de notificação de login gravado")
+
+	return template, nil
+}
+
+// DeleteTemplate remove um modelo; a mensagem volta a usar o modelo padrão
+func (s *LoginNotificationServiceImpl) DeleteTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) error {
+	if err := s.repository.DeleteTemplate(ctx, tenantID, templateID); err != nil {
+		if errors.Is(err, model.ErrLoginNotificationTemplateNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao remover modelo de notificação de login: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("template_id", templateID.String()).
+		Str("deleted_by", actorID.String()).
+		Msg("Modelo de notificação de login removido")
+
+	return nil
+}
+
+// ObserveLogin avalia o login e avisa o usuário quando vem de um dispositivo ou de uma
+// localização que não usou durante o período de retenção
+//
+// Os contextos são sempre registados, mesmo com as notificações desativadas, para que a
+// ativação não avise todos os usuários no login seguinte. As falhas de entrega ficam
+// registadas na notificação e não interrompem as entregas nos restantes canais.
+func (s *LoginNotificationServiceImpl) ObserveLogin(ctx context.Context, event *model.LoginEvent) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationServiceImpl.ObserveLogin", trace.WithAttributes(
+		attribute.String("tenant_id", event.TenantID.String()),
+		attribute.String("method", event.Method),
+	))
+	defer span.End()
+
+	contexts := s.loginContexts(event)
+	if len(contexts) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	seenAt := event.OccurredAt
+	if seenAt.IsZero() {
+		seenAt = now
+	}
+	unknown, firstLogin, err := s.repository.TouchLoginContexts(ctx, event.TenantID, event.UserID, contexts, seenAt, now.Add(-s.config.ContextRetention))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao registar contextos do login: %w", err)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	settings, err := s.GetSettings(ctx, event.TenantID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled || (firstLogin && !settings.NotifyFirstLogin) {
+		return nil
+	}
+
+	recipient, err := s.repository.GetRecipient(ctx, event.TenantID, event.UserID)
+	if err != nil {
+		return fmt.Errorf("erro ao obter contactos do usuário: %w", err)
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return err
+	}
+	notification := &model.LoginNotification{
+		ID:         uuid.New(),
+		TenantID:   event.TenantID,
+		UserID:     event.UserID,
+		Method:     event.Method,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		Country:    strings.ToUpper(strings.TrimSpace(event.Country)),
+		Contexts:   contexts,
+		Deliveries: []model.LoginNotificationDelivery{},
+		LoginAt:    seenAt,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.config.ResponseTTL),
+	}
+	for _, kind := range unknown {
+		switch kind {
+		case model.LoginContextDevice:
+			notification.Reasons = append(notification.Reasons, model.LoginNotificationReasonNewDevice)
+		case model.LoginContextLocation:
+			notification.Reasons = append(notification.Reasons, model.LoginNotificationReasonNewLocation)
+		}
+	}
+	notification.ResponseHash = s.sign("response", notification.ID.String()+"|"+secret)
+
+	if err := s.repository.CreateNotification(ctx, notification); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao gravar notificação de login: %w", err)
+	}
+
+	data := s.templateData(event, recipient)
+	data.ResponseURL = withTokenParameter(s.config.ResponseURL, s.responseToken(notification.ID, secret))
+	data.ExpiresAt = formatLoginNotificationTime(notification.ExpiresAt, recipient.Timezone)
+	notification.Deliveries = s.deliver(ctx, settings, recipient, notification.ID, model.LoginNotificationKindAlert, data)
+
+	if err := s.repository.SaveDeliveries(ctx, notification.TenantID, notification.ID, notification.Deliveries); err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", notification.TenantID.String()).
+			Str("notification_id", notification.ID.String()).
+			Msg("Erro ao gravar entregas da notificação de login")
+	}
+
+	reasons := make([]string, len(notification.Reasons))
+	for i, reason := range notification.Reasons {
+		reasons[i] = string(reason)
+	}
+	log.Info().
+		Str("tenant_id", notification.TenantID.String()).
+		Str("user_id", notification.UserID.String()).
+		Str("notification_id", notification.ID.String()).
+		Strs("reasons", reasons).
+		Str("ip_address", notification.IPAddress).
+		Int("deliveries", len(notification.Deliveries)).
+		Msg("Login suspeito notificado ao usuário")
+
+	return nil
+}
+
+// RespondNotMe trata a resposta "não fui eu" de uma notificação
+//
+// As sessões do usuário são terminadas como as sessões inativas: cada instância rejeita a
+// sessão no registo de atividade seguinte. A senha atual fica expirada e o usuário recebe um
+// link de redefinição nos canais configurados. Todas as falhas do token retornam o mesmo erro.
+func (s *LoginNotificationServiceImpl) RespondNotMe(ctx context.Context, tenantID uuid.UUID, token, ipAddress string) (*application.LoginNotMeResult, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationServiceImpl.RespondNotMe", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	notificationID, secret, ok := parseLoginResponseToken(token)
+	if !ok {
+		return nil, model.ErrInvalidLoginResponseToken
+	}
+
+	// Fluxo público, a partir do link da notificação: o tenant é o do pedido
+	ctx, err := requestctx.EnsureTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	notification, err := s.repository.GetNotification(ctx, tenantID, notificationID)
+	if err != nil {
+		if errors.Is(err, model.ErrLoginNotificationNotFound) {
+			return nil, model.ErrInvalidLoginResponseToken
+		}
+		return nil, fmt.Errorf("erro ao obter notificação de login: %w", err)
+	}
+
+	now := s.now()
+	if notification.RespondedAt != nil || !now.Before(notification.ExpiresAt) ||
+		!hmac.Equal([]byte(notification.ResponseHash), []byte(s.sign("response", notification.ID.String()+"|"+secret))) {
+		log.Warn().
+			Str("tenant_id", tenantID.String()).
+			Str("notification_id", notificationID.String()).
+			Str("ip_address", ipAddress).
+			Msg("Resposta a notificação de login rejeitada")
+		return nil, model.ErrInvalidLoginResponseToken
+	}
+
+	resetSecret, err := randomSecret()
+	if err != nil {
+		return nil, err
+	}
+	resetHash := sha256.Sum256([]byte(resetSecret))
+	reset := &model.CredentialResetToken{
+		ID:        uuid.New(),
+		UserID:    notification.UserID,
+		TokenHash: hex.EncodeToString(resetHash[:]),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.CredentialResetTTL),
+	}
+
+	sessionsEnded, err := s.repository.RecordNotMeResponse(ctx, notification, reset, now)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidLoginResponseToken) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao registar resposta à notificação de login: %w", err)
+	}
+
+	log.Warn().
+		Str("tenant_id", tenantID.String()).
+		Str("user_id", notification.UserID.String()).
+		Str("notification_id", notification.ID.String()).
+		Str("login_ip_address", notification.IPAddress).
+		Str("ip_address", ipAddress).
+		Int("sessions_ended", sessionsEnded).
+		Msg("Login não reconhecido pelo usuário: sessões terminadas e credencial por redefinir")
+
+	// As sessões já foram terminadas; uma falha no envio do link não anula a resposta
+	settings, err := s.GetSettings(ctx, tenantID)
+	if err == nil {
+		var recipient *model.LoginNotificationRecipient
+		recipient, err = s.repository.GetRecipient(ctx, tenantID, notification.UserID)
+		if err == nil {
+			data := s.templateData(&model.LoginEvent{OccurredAt: now, IPAddress: ipAddress}, recipient)
+			data.ResetURL = withTokenParameter(s.config.CredentialResetURL, resetSecret)
+			data.ExpiresAt = formatLoginNotificationTime(reset.ExpiresAt, recipient.Timezone)
+			s.deliver(ctx, settings, recipient, notification.ID, model.LoginNotificationKindCredentialReset, data)
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("notification_id", notification.ID.String()).
+			Msg("Erro ao enviar link de redefinição da credencial")
+	}
+
+	return &application.LoginNotMeResult{
+		NotificationID:           notification.ID,
+		SessionsEnded:            sessionsEnded,
+		CredentialResetExpiresAt: reset.ExpiresAt,
+	}, nil
+}
+
+// ListNotifications recupera as notificações mais recentes enviadas ao usuário
+func (s *LoginNotificationServiceImpl) ListNotifications(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LoginNotification, error) {
+	notifications, err := s.repository.ListNotifications(ctx, tenantID, userID, s.config.ListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar notificações de login: %w", err)
+	}
+	return notifications, nil
+}
+
+// loginContexts calcula as impressões do dispositivo e da localização do login
+// As impressões são por usuário, para que não revelem os contextos partilhados entre usuários
+func (s *LoginNotificationServiceImpl) loginContexts(event *model.LoginEvent) []model.LoginContextFingerprint {
+	var contexts []model.LoginContextFingerprint
+	prefix := event.TenantID.String() + "|" + event.UserID.String() + "|"
+	if key := event.DeviceKey(); key != "" {
+		contexts = append(contexts, model.LoginContextFingerprint{
+			Kind:        model.LoginContextDevice,
+			Fingerprint: s.sign("context|device", prefix+key),
+		})
+	}
+	if key := event.LocationKey(); key != "" {
+		contexts = append(contexts, model.LoginContextFingerprint{
+			Kind:        model.LoginContextLocation,
+			Fingerprint: s.sign("context|location", prefix+key),
+		})
+	}
+	return contexts
+}
+
+// deliver entrega a mensagem em cada canal configurado e retorna o resultado das entregas
+func (s *LoginNotificationServiceImpl) deliver(
+	ctx context.Context,
+	settings *model.LoginNotificationSettings,
+	recipient *model.LoginNotificationRecipient,
+	notificationID uuid.UUID,
+	kind model.LoginNotificationKind,
+	data *model.LoginNotificationTemplateData,
+) []model.LoginNotificationDelivery {
+	locale := settings.Locale(recipient.Locale)
+	templates, err := s.repository.ListTemplates(ctx, settings.TenantID)
+	if err != nil {
+		// Sem os modelos do tenant, a mensagem é entregue com o modelo padrão
+		log.Error().Err(err).Str("tenant_id", settings.TenantID.String()).Msg("Erro ao obter modelos de notificação de login")
+	}
+
+	deliveries := make([]model.LoginNotificationDelivery, 0, len(settings.Channels))
+	for _, channel := range settings.Channels {
+		delivery := model.LoginNotificationDelivery{Channel: channel, Status: model.LoginDeliverySkipped}
+
+		to := ""
+		switch channel {
+		case model.LoginNotificationChannelEmail:
+			to = recipient.Email
+		case model.LoginNotificationChannelSMS:
+			to = recipient.PhoneNumber
+		case model.LoginNotificationChannelPush:
+			to = recipient.UserID.String()
+		}
+		sender, ok := s.senders[channel]
+		if !ok || sender == nil || to == "" {
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+
+		template := selectLoginNotificationTemplate(templates, kind, channel, settings.Market, locale)
+		delivery.Locale = template.Locale
+		subject, body, err := template.Render(data)
+		if err == nil {
+			err = sender.Send(ctx, &application.LoginNotificationMessage{
+				TenantID:       recipient.TenantID,
+				UserID:         recipient.UserID,
+				NotificationID: notificationID,
+				Kind:           kind,
+				Channel:        channel,
+				Locale:         template.Locale,
+				To:             to,
+				Subject:        subject,
+				Body:           body,
+			})
+		}
+		if err != nil {
+			log.Warn().Err(err).
+				Str("tenant_id", recipient.TenantID.String()).
+				Str("notification_id", notificationID.String()).
+				Str("channel", string(channel)).
+				Msg("Falha na entrega da notificação de login")
+			delivery.Status = model.LoginDeliveryFailed
+			delivery.Error = err.Error()
+		} else {
+			delivery.Status = model.LoginDeliverySent
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// templateData monta os campos das mensagens no fuso horário do usuário
+func (s *LoginNotificationServiceImpl) templateData(event *model.LoginEvent, recipient *model.LoginNotificationRecipient) *model.LoginNotificationTemplateData {
+	data := &model.LoginNotificationTemplateData{
+		Name:      recipient.DisplayName,
+		Time:      formatLoginNotificationTime(event.OccurredAt, recipient.Timezone),
+		Device:    "-",
+		Location:  "-",
+		IPAddress: "-",
+	}
+	if data.Name == "" {
+		data.Name = recipient.Email
+	}
+	if ua := strings.TrimSpace(event.UserAgent); ua != "" {
+		if runes := []rune(ua); len(runes) > maxLoginNotificationDeviceSize {
+			ua = string(runes[:maxLoginNotificationDeviceSize]) + "…"
+		}
+		data.Device = ua
+	}
+	if event.IPAddress != "" {
+		data.IPAddress = event.IPAddress
+		data.Location = event.IPAddress
+	}
+	if country := strings.ToUpper(strings.TrimSpace(event.Country)); country != "" {
+		data.Location = country
+	}
+	return data
+}
+
+// responseToken monta o token do link "não fui eu" no formato <notificação>.<segredo>, em base64url
+func (s *LoginNotificationServiceImpl) responseToken(notificationID uuid.UUID, secret string) string {
+	return base64.RawURLEncoding.EncodeToString(notificationID[:]) + "." + secret
+}
+
+// sign calcula o HMAC do valor no domínio indicado, em base64url
+func (s *LoginNotificationServiceImpl) sign(domain, value string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	mac.Write([]byte(domain + "|" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseLoginResponseToken extrai a notificação e o segredo do token do link "não fui eu"
+func parseLoginResponseToken(token string) (uuid.UUID, string, bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 2 || parts[1] == "" {
+		return uuid.Nil, "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	notificationID, err := uuid.FromBytes(raw)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return notificationID, parts[1], true
+}
+
+// selectLoginNotificationTemplate escolhe o modelo do tenant para a mensagem, o canal, o mercado e
+// o idioma, preferindo o modelo do mercado ao modelo geral e o idioma exato à mesma língua.
+// Sem modelo do tenant, retorna o modelo padrão
+func selectLoginNotificationTemplate(templates []*model.LoginNotificationTemplate, kind model.LoginNotificationKind, channel model.LoginNotificationChannel, market, locale string) *model.LoginNotificationTemplate {
+	language := strings.SplitN(locale, "-", 2)[0]
+	var best *model.LoginNotificationTemplate
+	bestScore := 0
+	for _, template := range templates {
+		if template.Kind != kind || template.Channel != channel {
+			continue
+		}
+		if template.Market != "" && template.Market != market {
+			continue
+		}
+
+		score := 0
+		switch {
+		case template.Locale == locale:
+			score = 2
+		case strings.SplitN(template.Locale, "-", 2)[0] == language:
+			score = 1
+		default:
+			continue
+		}
+		if template.Market != "" {
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = template, score
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return model.BuiltinLoginNotificationTemplate(kind, channel, locale)
+}
+
+// formatLoginNotificationTime formata o instante no fuso horário do usuário, ou em UTC
+func formatLoginNotificationTime(t time.Time, timezone string) string {
+	location := time.UTC
+	if timezone != "" {
+		if loaded, err := time.LoadLocation(timezone); err == nil {
+			location = loaded
+		}
+	}
+	return t.In(location).Format("02/01/2006 15:04 MST")
+}
+
+// withTokenParameter acrescenta o token à página no parâmetro token
+func withTokenParameter(page, token string) string {
+	separator := "?"
+	if strings.Contains(page, "?") {
+		separator = "&"
+	}
+	return page + separator + "token=" + url.QueryEscape(token)
+}
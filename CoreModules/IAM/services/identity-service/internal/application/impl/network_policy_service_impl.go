@@ -0,0 +1,610 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das políticas de rede
+const (
+	DefaultNetworkPolicyCacheTTL     = 30 * time.Second
+	DefaultNetworkPolicyMaxDecisions = 10000
+	DefaultBreakGlassDuration        = time.Hour
+	DefaultMaxBreakGlassDuration     = 4 * time.Hour
+	DefaultBreakGlassUsesListLimit   = 100
+	MaxBreakGlassUsesListLimit       = 1000
+)
+
+// NetworkPolicyConfig configura a avaliação das políticas de rede
+type NetworkPolicyConfig struct {
+	// Tempo durante o qual as políticas e as decisões de um tenant ficam em cache;
+	// as alterações feitas nesta instância invalidam o cache de imediato
+	CacheTTL time.Duration
+	// Decisões guardadas por tenant; ao atingir o limite o cache do tenant é esvaziado
+	MaxCachedDecisions int
+	// Duração dos acessos de emergência sem duração indicada e duração máxima aceite
+	DefaultBreakGlassDuration time.Duration
+	MaxBreakGlassDuration     time.Duration
+}
+
+// DefaultNetworkPolicyConfig retorna a configuração padrão das políticas de rede
+func DefaultNetworkPolicyConfig() NetworkPolicyConfig {
+	return NetworkPolicyConfig{
+		CacheTTL:                  DefaultNetworkPolicyCacheTTL,
+		MaxCachedDecisions:        DefaultNetworkPolicyMaxDecisions,
+		DefaultBreakGlassDuration: DefaultBreakGlassDuration,
+		MaxBreakGlassDuration:     DefaultMaxBreakGlassDuration,
+	}
+}
+
+// compiledNetworkPolicy guarda os blocos de endereços já interpretados de uma política
+type compiledNetworkPolicy struct {
+	policy   *model.NetworkPolicy
+	networks []*net.IPNet
+}
+
+// matches indica se o endereço pertence a algum bloco da política
+func (p *compiledNetworkPolicy) matches(ip net.IP) bool {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkPolicySnapshot guarda em cache as políticas, os acessos de emergência em vigor e
+// as decisões de um tenant; as decisões guardadas não incluem o acesso de emergência,
+// que é verificado e auditado em cada pedido
+type networkPolicySnapshot struct {
+	policies  []*compiledNetworkPolicy
+	overrides []*model.BreakGlassOverride
+	decisions map[string]model.NetworkDecision
+	expiresAt time.Time
+}
+
+// NetworkPolicyServiceImpl implementa a interface NetworkPolicyService
+type NetworkPolicyServiceImpl struct {
+	repository repository.NetworkPolicyRepository
+	config     NetworkPolicyConfig
+	now        func() time.Time
+
+	mutex     sync.Mutex
+	snapshots map[uuid.UUID]*networkPolicySnapshot
+}
+
+// NewNetworkPolicyService cria uma nova instância de NetworkPolicyService
+// Os valores fora do intervalo válido usam os padrões
+func NewNetworkPolicyService(repo repository.NetworkPolicyRepository, config NetworkPolicyConfig) application.NetworkPolicyService {
+	defaults := DefaultNetworkPolicyConfig()
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaults.CacheTTL
+	}
+	if config.MaxCachedDecisions <= 0 {
+		config.MaxCachedDecisions = defaults.MaxCachedDecisions
+	}
+	if config.MaxBreakGlassDuration <= 0 {
+		config.MaxBreakGlassDuration = defaults.MaxBreakGlassDuration
+	}
+	if config.DefaultBreakGlassDuration <= 0 || config.DefaultBreakGlassDuration > config.MaxBreakGlassDuration {
+		config.DefaultBreakGlassDuration = minDuration(defaults.DefaultBreakGlassDuration, config.MaxBreakGlassDuration)
+	}
+
+	return &NetworkPolicyServiceImpl{
+		repository: repo,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+		snapshots:  make(map[uuid.UUID]*networkPolicySnapshot),
+	}
+}
+
+// ListPolicies recupera as políticas de rede do tenant
+func (s *NetworkPolicyServiceImpl) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.NetworkPolicy, error) {
+	policies, err := s.repository.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar políticas de rede: %w", err)
+	}
+	return policies, nil
+}
+
+// GetPolicy recupera uma política de rede do tenant
+func (s *NetworkPolicyServiceImpl) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.NetworkPolicy, error) {
+	policy, err := s.repository.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		if errors.Is(err, model.ErrNetworkPolicyNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter política de rede: %w", err)
+	}
+	return policy, nil
+}
+
+// CreatePolicy cria uma política de rede
+func (s *NetworkPolicyServiceImpl) CreatePolicy(ctx context.Context, req *application.SaveNetworkPolicyRequest) (*model.NetworkPolicy, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.CreatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("scope", string(req.Scope)),
+		attribute.String("action", string(req.Action)),
+	))
+	defer span.End()
+
+	now := s.now()
+	policy := &model.NetworkPolicy{
+		ID:        uuid.New(),
+		TenantID:  req.TenantID,
+		Name:      req.Name,
+		Scope:     req.Scope,
+		Action:    req.Action,
+		RoleCode:  req.RoleCode,
+		CIDRs:     req.CIDRs,
+		Enabled:   req.Enabled,
+		CreatedBy: req.ActorID,
+		UpdatedBy: req.ActorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := policy.Normalize(); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreatePolicy(ctx, policy); err != nil {
+		if errors.Is(err, model.ErrNetworkPolicyConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar política de rede: %w", err)
+	}
+	s.invalidate(policy.TenantID)
+
+	s.logPolicyChange(policy, "criada")
+	return policy, nil
+}
+
+// UpdatePolicy altera uma política de rede
+func (s *NetworkPolicyServiceImpl) UpdatePolicy(ctx context.Context, req *application.SaveNetworkPolicyRequest) (*model.NetworkPolicy, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.UpdatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("policy_id", req.PolicyID.String()),
+	))
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, req.TenantID, req.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Name = req.Name
+	policy.Scope = req.Scope
+	policy.Action = req.Action
+	policy.RoleCode = req.RoleCode
+	policy.CIDRs = req.CIDRs
+	policy.Enabled = req.Enabled
+	policy.UpdatedBy = req.ActorID
+	policy.UpdatedAt = s.now()
+	if err := policy.Normalize(); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdatePolicy(ctx, policy); err != nil {
+		if errors.Is(err, model.ErrNetworkPolicyNotFound) || errors.Is(err, model.ErrNetworkPolicyConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar política de rede: %w", err)
+	}
+	s.invalidate(policy.TenantID)
+
+	s.logPolicyChange(policy, "alterada")
+	return policy, nil
+}
+
+// DeletePolicy remove uma política de rede
+func (s *NetworkPolicyServiceImpl) DeletePolicy(ctx context.Context, tenantID, policyID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.DeletePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("policy_id", policyID.String()),
+	))
+	defer span.End()
+
+	if err := s.repository.DeletePolicy(ctx, tenantID, policyID); err != nil {
+		if errors.Is(err, model.ErrNetworkPolicyNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao remover política de rede: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("policy_id", policyID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Política de rede removida")
+	return nil
+}
+
+// Evaluate decide se o pedido é permitido pelas políticas de rede do tenant
+//
+// Aplicam-se as políticas ativas da API de administração (nos pedidos a essa API) e as das
+// funções do usuário. Um endereço bloqueado por qualquer política é recusado; depois, cada
+// grupo com lista de permitidos (a API de administração e cada função) tem de incluir o
+// endereço, para que a lista de uma função não seja contornada pela de outra.
+// Um pedido recusado é permitido pelo acesso de emergência em vigor do usuário, mas só
+// depois de o uso ficar gravado; sem auditoria o pedido continua recusado.
+func (s *NetworkPolicyServiceImpl) Evaluate(ctx context.Context, req *model.NetworkAccessRequest) (*model.NetworkDecision, error) {
+	now := s.now()
+	snapshot, err := s.snapshot(ctx, req.TenantID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	key := networkDecisionKey(req)
+	s.mutex.Lock()
+	decision, cached := snapshot.decisions[key]
+	s.mutex.Unlock()
+
+	if !cached {
+		decision = evaluateNetworkPolicies(snapshot.policies, req)
+		s.mutex.Lock()
+		if len(snapshot.decisions) >= s.config.MaxCachedDecisions {
+			snapshot.decisions = make(map[string]model.NetworkDecision)
+		}
+		snapshot.decisions[key] = decision
+		s.mutex.Unlock()
+	}
+
+	if decision.Allowed || req.UserID == uuid.Nil {
+		return &decision, nil
+	}
+
+	override := activeBreakGlass(snapshot.overrides, req.UserID, now)
+	if override == nil {
+		return &decision, nil
+	}
+	return s.useBreakGlass(ctx, override, req, decision, now), nil
+}
+
+// useBreakGlass grava o uso do acesso de emergência e permite o pedido
+// Se o registo falhar, o pedido é recusado
+func (s *NetworkPolicyServiceImpl) useBreakGlass(ctx context.Context, override *model.BreakGlassOverride, req *model.NetworkAccessRequest, blocked model.NetworkDecision, now time.Time) *model.NetworkDecision {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.useBreakGlass", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("break_glass_id", override.ID.String()),
+	))
+	defer span.End()
+
+	use := &model.BreakGlassUse{
+		ID:            uuid.New(),
+		OverrideID:    override.ID,
+		TenantID:      req.TenantID,
+		UserID:        req.UserID,
+		IPAddress:     req.IPAddress,
+		Method:        req.Method,
+		Path:          req.Path,
+		BlockedReason: blocked.Reason,
+		PolicyID:      blocked.PolicyID,
+		UsedAt:        now,
+	}
+	if err := s.repository.RecordBreakGlassUse(ctx, use); err != nil {
+		span.RecordError(err)
+		log.Error().Err(err).
+			Str("tenant_id", req.TenantID.String()).
+			Str("user_id", req.UserID.String()).
+			Str("break_glass_id", override.ID.String()).
+			Msg("Acesso de emergência recusado: falha ao auditar o uso")
+		return &model.NetworkDecision{
+			Allowed:  false,
+			Reason:   model.NetworkBlockReasonAuditFailure,
+			Scope:    blocked.Scope,
+			RoleCode: blocked.RoleCode,
+			PolicyID: blocked.PolicyID,
+		}
+	}
+
+	log.Warn().
+		Str("tenant_id", req.TenantID.String()).
+		Str("user_id", req.UserID.String()).
+		Str("break_glass_id", override.ID.String()).
+		Str("ip_address", req.IPAddress).
+		Str("blocked_reason", blocked.Reason).
+		Str("path", req.Path).
+		Msg("Pedido bloqueado pela política de rede permitido por acesso de emergência")
+
+	allowed := blocked
+	allowed.Allowed = true
+	allowed.BreakGlassID = &override.ID
+	return &allowed
+}
+
+// ActivateBreakGlass ativa um acesso de emergência temporário para um usuário
+func (s *NetworkPolicyServiceImpl) ActivateBreakGlass(ctx context.Context, req *application.ActivateBreakGlassRequest) (*model.BreakGlassOverride, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.ActivateBreakGlass", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("granted_by", req.GrantedBy.String()),
+	))
+	defer span.End()
+
+	if req.TenantID == uuid.Nil {
+		return nil, model.ErrInvalidTenantID
+	}
+	if req.UserID == uuid.Nil || req.GrantedBy == uuid.Nil {
+		return nil, fmt.Errorf("%w: o usuário e o responsável pela ativação são obrigatórios", model.ErrInvalidBreakGlass)
+	}
+	justification := strings.TrimSpace(req.Justification)
+	if len([]rune(justification)) < model.MinBreakGlassJustificationSize {
+		return nil, fmt.Errorf("%w: a justificação deve ter pelo menos %d caracteres",
+			model.ErrInvalidBreakGlass, model.MinBreakGlassJustificationSize)
+	}
+	duration := req.Duration
+	if duration == 0 {
+		duration = s.config.DefaultBreakGlassDuration
+	}
+	if duration < 0 || duration > s.config.MaxBreakGlassDuration {
+		return nil, fmt.Errorf("%w: a duração deve estar entre 0 e %s",
+			model.ErrInvalidBreakGlass, s.config.MaxBreakGlassDuration)
+	}
+
+	now := s.now()
+	override := &model.BreakGlassOverride{
+		ID:            uuid.New(),
+		TenantID:      req.TenantID,
+		UserID:        req.UserID,
+		Justification: justification,
+		TicketRef:     strings.TrimSpace(req.TicketRef),
+		GrantedBy:     req.GrantedBy,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(duration),
+	}
+	if err := s.repository.CreateBreakGlass(ctx, override); err != nil {
+		return nil, fmt.Errorf("erro ao gravar acesso de emergência: %w", err)
+	}
+	s.invalidate(req.TenantID)
+
+	log.Warn().
+		Str("tenant_id", override.TenantID.String()).
+		Str("user_id", override.UserID.String()).
+		Str("granted_by", override.GrantedBy.String()).
+		Str("break_glass_id", override.ID.String()).
+		Str("ticket_ref", override.TicketRef).
+		Time("expires_at", override.ExpiresAt).
+		Msg("Acesso de emergência às políticas de rede ativado")
+
+	return override, nil
+}
+
+// RevokeBreakGlass revoga um acesso de emergência antes de expirar
+func (s *NetworkPolicyServiceImpl) RevokeBreakGlass(ctx context.Context, tenantID, overrideID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyServiceImpl.RevokeBreakGlass", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("break_glass_id", overrideID.String()),
+	))
+	defer span.End()
+
+	if err := s.repository.RevokeBreakGlass(ctx, tenantID, overrideID, actorID, s.now()); err != nil {
+		if errors.Is(err, model.ErrBreakGlassNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao revogar acesso de emergência: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("break_glass_id", overrideID.String()).
+		Str("revoked_by", actorID.String()).
+		Msg("Acesso de emergência às políticas de rede revogado")
+	return nil
+}
+
+// ListBreakGlass recupera os acessos de emergência do tenant
+func (s *NetworkPolicyServiceImpl) ListBreakGlass(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]*model.BreakGlassOverride, error) {
+	var activeAt *time.Time
+	if activeOnly {
+		now := s.now()
+		activeAt = &now
+	}
+	overrides, err := s.repository.ListBreakGlass(ctx, tenantID, activeAt)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar acessos de emergência: %w", err)
+	}
+	return overrides, nil
+}
+
+// ListBreakGlassUses recupera o registo de auditoria de um acesso de emergência
+func (s *NetworkPolicyServiceImpl) ListBreakGlassUses(ctx context.Context, tenantID, overrideID uuid.UUID, limit int) ([]*model.BreakGlassUse, error) {
+	if limit <= 0 {
+		limit = DefaultBreakGlassUsesListLimit
+	}
+	if limit > MaxBreakGlassUsesListLimit {
+		limit = MaxBreakGlassUsesListLimit
+	}
+	uses, err := s.repository.ListBreakGlassUses(ctx, tenantID, overrideID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar usos do acesso de emergência: %w", err)
+	}
+	return uses, nil
+}
+
+// snapshot retorna as políticas e os acessos de emergência do tenant, carregando-os quando
+// o cache expirou
+func (s *NetworkPolicyServiceImpl) snapshot(ctx context.Context, tenantID uuid.UUID, now time.Time) (*networkPolicySnapshot, error) {
+	s.mutex.Lock()
+	snapshot, ok := s.snapshots[tenantID]
+	s.mutex.Unlock()
+	if ok && now.Before(snapshot.expiresAt) {
+		return snapshot, nil
+	}
+
+	policies, err := s.repository.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar políticas de rede: %w", err)
+	}
+	overrides, err := s.repository.ListBreakGlass(ctx, tenantID, &now)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar acessos de emergência: %w", err)
+	}
+
+	snapshot = &networkPolicySnapshot{
+		overrides: overrides,
+		decisions: make(map[string]model.NetworkDecision),
+		expiresAt: now.Add(s.config.CacheTTL),
+	}
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		compiled := &compiledNetworkPolicy{policy: policy}
+		for _, cidr := range policy.CIDRs {
+			network, err := model.ParseNetworkCIDR(cidr)
+			if err != nil {
+				// Uma política gravada inválida não pode abrir o acesso: é ignorada e registada
+				log.Error().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("policy_id", policy.ID.String()).
+					Msg("Bloco de endereços inválido na política de rede")
+				continue
+			}
+			compiled.networks = append(compiled.networks, network)
+		}
+		snapshot.policies = append(snapshot.policies, compiled)
+	}
+
+	s.mutex.Lock()
+	s.snapshots[tenantID] = snapshot
+	s.mutex.Unlock()
+	return snapshot, nil
+}
+
+// invalidate descarta o cache do tenant após uma alteração
+func (s *NetworkPolicyServiceImpl) invalidate(tenantID uuid.UUID) {
+	s.mutex.Lock()
+	delete(s.snapshots, tenantID)
+	s.mutex.Unlock()
+}
+
+// logPolicyChange regista a alteração de uma política
+func (s *NetworkPolicyServiceImpl) logPolicyChange(policy *model.NetworkPolicy, change string) {
+	log.Info().
+		Str("tenant_id", policy.TenantID.String()).
+		Str("policy_id", policy.ID.String()).
+		Str("scope", string(policy.Scope)).
+		Str("action", string(policy.Action)).
+		Str("role_code", policy.RoleCode).
+		Int("cidrs", len(policy.CIDRs)).
+		Bool("enabled", policy.Enabled).
+		Str("actor_id", policy.UpdatedBy.String()).
+		Msgf("Política de rede %s", change)
+}
+
+// evaluateNetworkPolicies aplica as políticas ao pedido, sem considerar o acesso de emergência
+func evaluateNetworkPolicies(policies []*compiledNetworkPolicy, req *model.NetworkAccessRequest) model.NetworkDecision {
+	var applicable []*compiledNetworkPolicy
+	for _, compiled := range policies {
+		if compiled.policy.AppliesTo(req) {
+			applicable = append(applicable, compiled)
+		}
+	}
+	if len(applicable) == 0 {
+		return model.NetworkDecision{Allowed: true}
+	}
+
+	ip := net.ParseIP(req.IPAddress)
+	if ip == nil {
+		return blockedBy(applicable[0].policy, model.NetworkBlockReasonInvalidIP)
+	}
+
+	for _, compiled := range applicable {
+		if compiled.policy.Action == model.NetworkPolicyActionDeny && compiled.matches(ip) {
+			return blockedBy(compiled.policy, model.NetworkBlockReasonDenied)
+		}
+	}
+
+	// Agrupar as listas de permitidos: API de administração e cada função
+	groups := make(map[string][]*compiledNetworkPolicy)
+	var order []string
+	for _, compiled := range applicable {
+		if compiled.policy.Action != model.NetworkPolicyActionAllow {
+			continue
+		}
+		group := string(compiled.policy.Scope) + ":" + strings.ToLower(compiled.policy.RoleCode)
+		if _, ok := groups[group]; !ok {
+			order = append(order, group)
+		}
+		groups[group] = append(groups[group], compiled)
+	}
+	for _, group := range order {
+		allowed := false
+		for _, compiled := range groups[group] {
+			if compiled.matches(ip) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return blockedBy(groups[group][0].policy, model.NetworkBlockReasonNotAllowed)
+		}
+	}
+
+	return model.NetworkDecision{Allowed: true}
+}
+
+// blockedBy cria a decisão de bloqueio pela política indicada
+func blockedBy(policy *model.NetworkPolicy, reason string) model.NetworkDecision {
+	policyID := policy.ID
+	return model.NetworkDecision{
+		Allowed:  false,
+		Reason:   reason,
+		Scope:    policy.Scope,
+		RoleCode: policy.RoleCode,
+		PolicyID: &policyID,
+	}
+}
+
+// activeBreakGlass retorna o acesso de emergência do usuário em vigor, se existir
+func activeBreakGlass(overrides []*model.BreakGlassOverride, userID uuid.UUID, now time.Time) *model.BreakGlassOverride {
+	for _, override := range overrides {
+		if override.UserID == userID && override.IsActive(now) {
+			return override
+		}
+	}
+	return nil
+}
+
+// networkDecisionKey identifica as decisões equivalentes: o mesmo endereço, o mesmo tipo de
+// rota e o mesmo conjunto de funções
+func networkDecisionKey(req *model.NetworkAccessRequest) string {
+	roles := make([]string, len(req.Roles))
+	for i, role := range req.Roles {
+		roles[i] = strings.ToLower(role)
+	}
+	sort.Strings(roles)
+	return fmt.Sprintf("%s|%t|%s", req.IPAddress, req.AdminAPI, strings.Join(roles, ","))
+}
+
+// minDuration retorna a menor das durações
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,549 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Valores padrão da autenticação sem senha
+const (
+	DefaultPasswordlessMagicLinkTTL    = 15 * time.Minute
+	DefaultPasswordlessOTPTTL          = 10 * time.Minute
+	DefaultPasswordlessOTPDigits       = 6
+	DefaultPasswordlessMaxAttempts     = 5
+	DefaultPasswordlessRateLimitWindow = 15 * time.Minute
+	DefaultPasswordlessMaxPerEmail     = 5
+	DefaultPasswordlessMaxPerIP        = 20
+	// Tempo máximo da avaliação de um login pelo LoginObserver, feita depois da resposta
+	DefaultPasswordlessObserverTimeout = 30 * time.Second
+)
+
+// Tamanho mínimo da chave de assinatura dos links e dos segredos gravados
+const MinPasswordlessSigningKeySize = 32
+
+// PasswordlessConfig configura a emissão e a verificação dos desafios sem senha
+type PasswordlessConfig struct {
+	// Chave HMAC que assina os links mágicos e protege os segredos e dispositivos gravados
+	SigningKey []byte
+	// Página que recebe o link mágico; o token é acrescentado no parâmetro token
+	LinkBaseURL string
+	// Validade dos links mágicos e dos códigos
+	MagicLinkTTL time.Duration
+	OTPTTL       time.Duration
+	// Número de dígitos dos códigos
+	OTPDigits int
+	// Tentativas de verificação de cada desafio
+	MaxAttempts int
+	// Pedidos aceites por email e por endereço IP em cada janela
+	RateLimitWindow time.Duration
+	MaxPerEmail     int
+	MaxPerIP        int
+	// LoginObserver é avisado de cada login concluído (ex.: notificações de login suspeito); opcional
+	LoginObserver application.LoginObserver
+}
+
+// DefaultPasswordlessConfig retorna a configuração padrão da autenticação sem senha
+// A chave de assinatura e a página do link mágico não têm valor padrão
+func DefaultPasswordlessConfig() PasswordlessConfig {
+	return PasswordlessConfig{
+		MagicLinkTTL:    DefaultPasswordlessMagicLinkTTL,
+		OTPTTL:          DefaultPasswordlessOTPTTL,
+		OTPDigits:       DefaultPasswordlessOTPDigits,
+		MaxAttempts:     DefaultPasswordlessMaxAttempts,
+		RateLimitWindow: DefaultPasswordlessRateLimitWindow,
+		MaxPerEmail:     DefaultPasswordlessMaxPerEmail,
+		MaxPerIP:        DefaultPasswordlessMaxPerIP,
+	}
+}
+
+// PasswordlessServiceImpl implementa a interface PasswordlessService
+type PasswordlessServiceImpl struct {
+	repository repository.PasswordlessRepository
+	sender     application.PasswordlessSender
+	legalGate  application.LegalAcceptanceGate
+	config     PasswordlessConfig
+	now        func() time.Time
+}
+
+// NewPasswordlessService cria uma nova instância de PasswordlessService
+// A chave de assinatura deve ter pelo menos MinPasswordlessSigningKeySize bytes;
+// os restantes valores fora do intervalo válido usam os padrões.
+// Com legalGate, o login só é concluído depois de aceites os documentos legais obrigatórios
+func NewPasswordlessService(
+	repo repository.PasswordlessRepository,
+	sender application.PasswordlessSender,
+	legalGate application.LegalAcceptanceGate,
+	config PasswordlessConfig,
+) application.PasswordlessService {
+	defaults := DefaultPasswordlessConfig()
+	if config.MagicLinkTTL <= 0 {
+		config.MagicLinkTTL = defaults.MagicLinkTTL
+	}
+	if config.OTPTTL <= 0 {
+		config.OTPTTL = defaults.OTPTTL
+	}
+	if config.OTPDigits < 6 || config.OTPDigits > 10 {
+		config.OTPDigits = defaults.OTPDigits
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RateLimitWindow <= 0 {
+		config.RateLimitWindow = defaults.RateLimitWindow
+	}
+	if config.MaxPerEmail <= 0 {
+		config.MaxPerEmail = defaults.MaxPerEmail
+	}
+	if config.MaxPerIP <= 0 {
+		config.MaxPerIP = defaults.MaxPerIP
+	}
+
+	return &PasswordlessServiceImpl{
+		repository: repo,
+		sender:     sender,
+		legalGate:  legalGate,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GetSettings recupera a configuração sem senha do tenant
+// Os tenants que nunca a configuraram têm o modo sem senha desativado
+func (s *PasswordlessServiceImpl) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.PasswordlessSettings, error) {
+	settings, err := s.repository.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter configuração sem senha: %w", err)
+	}
+	if settings == nil {
+		return model.DefaultPasswordlessSettings(tenantID), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings altera a configuração sem senha do tenant, respeitando a política do mercado
+func (s *PasswordlessServiceImpl) UpdateSettings(ctx context.Context, req *application.UpdatePasswordlessSettingsRequest) (*model.PasswordlessSettings, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessServiceImpl.UpdateSettings", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.Bool("enabled", req.Enabled),
+	))
+	defer span.End()
+
+	settings := &model.PasswordlessSettings{
+		TenantID:        req.TenantID,
+		Enabled:         req.Enabled,
+		Methods:         req.Methods,
+		DeviceBinding:   req.DeviceBinding,
+		Market:          strings.ToLower(strings.TrimSpace(req.Market)),
+		FinancialTenant: req.FinancialTenant,
+		UpdatedBy:       req.UpdatedBy,
+		UpdatedAt:       s.now(),
+	}
+	if settings.DeviceBinding == "" {
+		settings.DeviceBinding = model.PasswordlessDeviceBindingOptional
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SaveSettings(ctx, settings); err != nil {
+		return nil, fmt.Errorf("erro ao gravar configuração sem senha: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", settings.TenantID.String()).
+		Bool("enabled", settings.Enabled).
+		Str("device_binding", string(settings.DeviceBinding)).
+		Str("market", settings.Market).
+		Bool("financial_tenant", settings.FinancialTenant).
+		Str("updated_by", settings.UpdatedBy.String()).
+		Msg("Configuração de autenticação sem senha atualizada")
+
+	return settings, nil
+}
+
+// StartLogin emite um link mágico ou um código e envia-o para o email indicado
+// Os emails sem conta ativa recebem um desafio que nunca é enviado, para que a resposta não
+// revele se a conta existe. Apenas o HMAC do segredo fica gravado.
+func (s *PasswordlessServiceImpl) StartLogin(ctx context.Context, req *application.StartPasswordlessLoginRequest) (*application.PasswordlessChallengeIssued, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessServiceImpl.StartLogin", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("method", string(req.Method)),
+	))
+	defer span.End()
+
+	// Fluxo público: o tenant é o do pedido de login
+	ctx, err := requestctx.EnsureTenant(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if !strings.Contains(email, "@") {
+		return nil, model.ErrInvalidEmail
+	}
+
+	settings, err := s.loginSettings(ctx, req.TenantID, req.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceHash := ""
+	if settings.DeviceBinding != model.PasswordlessDeviceBindingNone && req.DeviceID != "" {
+		deviceHash = s.sign("device", req.DeviceID)
+	}
+	if settings.DeviceBinding == model.PasswordlessDeviceBindingRequired && deviceHash == "" {
+		return nil, model.ErrPasswordlessDeviceRequired
+	}
+
+	now := s.now()
+	byEmail, byIP, err := s.repository.CountRecentChallenges(ctx, req.TenantID, email, req.IPAddress, now.Add(-s.config.RateLimitWindow))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar pedidos sem senha recentes: %w", err)
+	}
+	if byEmail >= s.config.MaxPerEmail || (req.IPAddress != "" && byIP >= s.config.MaxPerIP) {
+		log.Warn().
+			Str("tenant_id", req.TenantID.String()).
+			Str("ip_address", req.IPAddress).
+			Int("by_email", byEmail).
+			Int("by_ip", byIP).
+			Msg("Pedidos de autenticação sem senha limitados")
+		return nil, model.ErrPasswordlessRateLimited
+	}
+
+	userID := uuid.Nil
+	user, err := s.repository.FindUserByEmail(ctx, req.TenantID, email)
+	switch {
+	case errors.Is(err, model.ErrUserNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("erro ao obter usuário: %w", err)
+	case user.CanAuthenticate():
+		userID = user.ID
+	}
+
+	ttl := s.config.MagicLinkTTL
+	if req.Method == model.PasswordlessMethodEmailOTP {
+		ttl = s.config.OTPTTL
+	}
+	challenge := &model.PasswordlessChallenge{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		UserID:      userID,
+		Email:       email,
+		Method:      req.Method,
+		DeviceHash:  deviceHash,
+		IPAddress:   req.IPAddress,
+		UserAgent:   req.UserAgent,
+		MaxAttempts: s.config.MaxAttempts,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	var secret, delivery string
+	switch req.Method {
+	case model.PasswordlessMethodMagicLink:
+		nonce := make([]byte, 32)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("erro ao gerar link mágico: %w", err)
+		}
+		secret = base64.RawURLEncoding.EncodeToString(nonce)
+		delivery = s.magicLink(challenge.ID, secret)
+	case model.PasswordlessMethodEmailOTP:
+		secret, err = newPasswordlessCode(s.config.OTPDigits)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao gerar código de uso único: %w", err)
+		}
+		delivery = secret
+	}
+	challenge.SecretHash = s.secretHash(challenge.Method, challenge.ID, secret)
+
+	if err := s.repository.CreateChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("erro ao gravar desafio sem senha: %w", err)
+	}
+
+	if userID != uuid.Nil {
+		if req.Method == model.PasswordlessMethodMagicLink {
+			err = s.sender.SendMagicLink(ctx, req.TenantID, email, delivery, challenge.ExpiresAt)
+		} else {
+			err = s.sender.SendOTP(ctx, req.TenantID, email, delivery, challenge.ExpiresAt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao enviar desafio sem senha: %w", err)
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", req.TenantID.String()).
+		Str("challenge_id", challenge.ID.String()).
+		Str("method", string(challenge.Method)).
+		Bool("delivered", userID != uuid.Nil).
+		Bool("device_bound", deviceHash != "").
+		Msg("Desafio de autenticação sem senha emitido")
+
+	return &application.PasswordlessChallengeIssued{
+		ChallengeID: challenge.ID,
+		Method:      challenge.Method,
+		ExpiresAt:   challenge.ExpiresAt,
+	}, nil
+}
+
+// VerifyMagicLink conclui o login com o token do link mágico
+// Os tokens com assinatura inválida são rejeitados sem consultar a base de dados
+func (s *PasswordlessServiceImpl) VerifyMagicLink(ctx context.Context, req *application.VerifyPasswordlessRequest) (*application.PasswordlessLoginResult, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessServiceImpl.VerifyMagicLink", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+	))
+	defer span.End()
+
+	challengeID, secret, ok := s.parseMagicLinkToken(req.Token)
+	if !ok {
+		return nil, model.ErrInvalidPasswordlessToken
+	}
+	return s.verify(ctx, req, model.PasswordlessMethodMagicLink, challengeID, secret)
+}
+
+// VerifyOTP conclui o login com o código enviado por email
+// Cada código aceita um número limitado de tentativas, após o qual deixa de ser válido
+func (s *PasswordlessServiceImpl) VerifyOTP(ctx context.Context, req *application.VerifyPasswordlessRequest) (*application.PasswordlessLoginResult, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessServiceImpl.VerifyOTP", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("challenge_id", req.ChallengeID.String()),
+	))
+	defer span.End()
+
+	code := strings.TrimSpace(req.Code)
+	if req.ChallengeID == uuid.Nil || len(code) != s.config.OTPDigits || strings.Trim(code, "0123456789") != "" {
+		return nil, model.ErrInvalidPasswordlessToken
+	}
+	return s.verify(ctx, req, model.PasswordlessMethodEmailOTP, req.ChallengeID, code)
+}
+
+// verify valida o segredo e o dispositivo do desafio e consome-o
+// A tentativa é registada antes da comparação, para que tentativas concorrentes não
+// ultrapassem o limite; todas as falhas retornam o mesmo erro
+func (s *PasswordlessServiceImpl) verify(ctx context.Context, req *application.VerifyPasswordlessRequest, method model.PasswordlessMethod, challengeID uuid.UUID, secret string) (*application.PasswordlessLoginResult, error) {
+	ctx, err := requestctx.EnsureTenant(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.loginSettings(ctx, req.TenantID, method)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	challenge, err := s.repository.ClaimAttempt(ctx, challengeID, now)
+	if err != nil {
+		if errors.Is(err, model.ErrPasswordlessChallengeNotFound) {
+			return nil, model.ErrInvalidPasswordlessToken
+		}
+		return nil, fmt.Errorf("erro ao obter desafio sem senha: %w", err)
+	}
+	if challenge.TenantID != req.TenantID || challenge.Method != method || challenge.UserID == uuid.Nil ||
+		!hmac.Equal([]byte(challenge.SecretHash), []byte(s.secretHash(method, challenge.ID, secret))) {
+		log.Warn().
+			Str("tenant_id", req.TenantID.String()).
+			Str("challenge_id", challengeID.String()).
+			Int("attempts", challenge.Attempts).
+			Msg("Verificação sem senha rejeitada")
+		return nil, model.ErrInvalidPasswordlessToken
+	}
+
+	deviceBound := false
+	switch {
+	case challenge.DeviceHash != "":
+		if req.DeviceID == "" || !hmac.Equal([]byte(challenge.DeviceHash), []byte(s.sign("device", req.DeviceID))) {
+			log.Warn().
+				Str("tenant_id", req.TenantID.String()).
+				Str("challenge_id", challengeID.String()).
+				Msg("Desafio sem senha concluído noutro dispositivo")
+			return nil, model.ErrInvalidPasswordlessToken
+		}
+		deviceBound = true
+	case settings.DeviceBinding == model.PasswordlessDeviceBindingRequired:
+		// Desafio emitido antes de a vinculação passar a ser obrigatória
+		return nil, model.ErrPasswordlessDeviceRequired
+	}
+
+	user, err := s.repository.GetUser(ctx, challenge.TenantID, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, model.ErrUserNotFound) {
+			return nil, model.ErrInvalidPasswordlessToken
+		}
+		return nil, fmt.Errorf("erro ao obter usuário: %w", err)
+	}
+	if !user.CanAuthenticate() {
+		return nil, model.ErrInvalidPasswordlessToken
+	}
+
+	if err := s.repository.ConsumeChallenge(ctx, challenge.ID, now); err != nil {
+		if errors.Is(err, model.ErrPasswordlessChallengeNotFound) {
+			return nil, model.ErrInvalidPasswordlessToken
+		}
+		return nil, fmt.Errorf("erro ao consumir desafio sem senha: %w", err)
+	}
+
+	result := &application.PasswordlessLoginResult{
+		User:            user,
+		Method:          method,
+		DeviceBound:     deviceBound,
+		AuthenticatedAt: now,
+	}
+
+	if s.legalGate != nil {
+		// O desafio já foi consumido: o usuário aceita os documentos com a autenticação obtida
+		result.PendingDocuments, err = s.legalGate.LoginPendingDocuments(ctx, challenge.TenantID, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar documentos legais por aceitar: %w", err)
+		}
+		if len(result.PendingDocuments) > 0 {
+			log.Info().
+				Str("tenant_id", challenge.TenantID.String()).
+				Str("user_id", user.ID.String()).
+				Int("pending_documents", len(result.PendingDocuments)).
+				Msg("Login sem senha à espera da aceitação de documentos legais")
+			return result, nil
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", challenge.TenantID.String()).
+		Str("user_id", user.ID.String()).
+		Str("method", string(method)).
+		Bool("device_bound", deviceBound).
+		Msg("Login sem senha concluído")
+
+	s.observeLogin(ctx, &model.LoginEvent{
+		TenantID:   challenge.TenantID,
+		UserID:     user.ID,
+		Method:     string(method),
+		DeviceID:   req.DeviceID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		Country:    req.Country,
+		OccurredAt: now,
+	})
+
+	return result, nil
+}
+
+// observeLogin avisa o LoginObserver do login em segundo plano, para que o envio das
+// notificações não atrase a resposta; as falhas são apenas registadas
+func (s *PasswordlessServiceImpl) observeLogin(ctx context.Context, event *model.LoginEvent) {
+	if s.config.LoginObserver == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultPasswordlessObserverTimeout)
+	go func() {
+		defer cancel()
+		if err := s.config.LoginObserver.ObserveLogin(ctx, event); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", event.TenantID.String()).
+				Str("user_id", event.UserID.String()).
+				Msg("Erro ao avaliar login sem senha para notificação")
+		}
+	}()
+}
+
+// loginSettings recupera a configuração do tenant e verifica que o método pode ser usado
+// A política do mercado é reavaliada para que uma restrição nova se aplique de imediato
+func (s *PasswordlessServiceImpl) loginSettings(ctx context.Context, tenantID uuid.UUID, method model.PasswordlessMethod) (*model.PasswordlessSettings, error) {
+	settings, err := s.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, model.ErrPasswordlessDisabled
+	}
+	if err := settings.CheckMarketPolicy(); err != nil {
+		log.Warn().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("market", settings.Market).
+			Msg("Autenticação sem senha bloqueada pela política do mercado")
+		return nil, model.ErrPasswordlessNotAllowed
+	}
+	if !settings.Allows(method) {
+		return nil, model.ErrPasswordlessMethodNotAllowed
+	}
+	return settings, nil
+}
+
+// magicLink monta o link enviado ao usuário
+// O token tem o formato <desafio>.<segredo>.<assinatura>, em base64url
+func (s *PasswordlessServiceImpl) magicLink(challengeID uuid.UUID, secret string) string {
+	id := base64.RawURLEncoding.EncodeToString(challengeID[:])
+	payload := id + "." + secret
+	token := payload + "." + s.sign("token", payload)
+
+	separator := "?"
+	if strings.Contains(s.config.LinkBaseURL, "?") {
+		separator = "&"
+	}
+	return s.config.LinkBaseURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// parseMagicLinkToken valida a assinatura do token e extrai o desafio e o segredo
+func (s *PasswordlessServiceImpl) parseMagicLinkToken(token string) (uuid.UUID, string, bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return uuid.Nil, "", false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign("token", payload))) {
+		return uuid.Nil, "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	challengeID, err := uuid.FromBytes(raw)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return challengeID, parts[1], true
+}
+
+// secretHash calcula o HMAC gravado para o segredo do desafio
+// O HMAC com a chave do serviço impede que os códigos curtos sejam descobertos a partir da base de dados
+func (s *PasswordlessServiceImpl) secretHash(method model.PasswordlessMethod, challengeID uuid.UUID, secret string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	mac.Write([]byte("secret|" + string(method) + "|" + challengeID.String() + "|" + secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sign calcula o HMAC do valor no domínio indicado, em base64url
+func (s *PasswordlessServiceImpl) sign(domain, value string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	mac.Write([]byte(domain + "|" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newPasswordlessCode gera um código numérico aleatório com o número de dígitos indicado
+func newPasswordlessCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
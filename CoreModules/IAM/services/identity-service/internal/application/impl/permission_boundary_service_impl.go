@@ -0,0 +1,398 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão dos limites de permissões
+const (
+	DefaultPermissionBoundaryCacheTTL = time.Minute
+)
+
+// cachedPermissionBoundaries guarda os limites de um tenant
+type cachedPermissionBoundaries struct {
+	boundaries []*model.PermissionBoundary
+	expiresAt  time.Time
+}
+
+// PermissionBoundaryServiceImpl implementa a interface PermissionBoundaryService
+// Os limites do tenant ficam em cache, porque são consultados em cada decisão de autorização;
+// o cache do tenant é descartado nas alterações feitas por esta instância
+type PermissionBoundaryServiceImpl struct {
+	repository repository.PermissionBoundaryRepository
+	incidents  repository.SecurityIncidentRepository
+	publisher  event.Publisher
+	cacheTTL   time.Duration
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	tenants map[uuid.UUID]cachedPermissionBoundaries
+}
+
+// NewPermissionBoundaryService cria uma nova instância de PermissionBoundaryService
+// As violações são gravadas como incidentes de segurança e publicadas no publicador indicado, que
+// pode ser nulo; um tempo de cache não positivo usa o valor padrão
+func NewPermissionBoundaryService(
+	repo repository.PermissionBoundaryRepository,
+	incidents repository.SecurityIncidentRepository,
+	publisher event.Publisher,
+	cacheTTL time.Duration,
+) application.PermissionBoundaryService {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultPermissionBoundaryCacheTTL
+	}
+
+	return &PermissionBoundaryServiceImpl{
+		repository: repo,
+		incidents:  incidents,
+		publisher:  publisher,
+		cacheTTL:   cacheTTL,
+		now:        func() time.Time { return time.Now().UTC() },
+		tenants:    make(map[uuid.UUID]cachedPermissionBoundaries),
+	}
+}
+
+// ListBoundaries recupera os limites do tenant
+func (s *PermissionBoundaryServiceImpl) ListBoundaries(ctx context.Context, tenantID uuid.UUID) ([]*model.PermissionBoundary, error) {
+	cached, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries := make([]*model.PermissionBoundary, len(cached.boundaries))
+	for i, boundary := range cached.boundaries {
+		copied := *boundary
+		boundaries[i] = &copied
+	}
+	return boundaries, nil
+}
+
+// GetBoundary recupera um limite do tenant
+func (s *PermissionBoundaryServiceImpl) GetBoundary(ctx context.Context, tenantID, boundaryID uuid.UUID) (*model.PermissionBoundary, error) {
+	boundary, err := s.repository.Get(ctx, tenantID, boundaryID)
+	if err != nil {
+		if errors.Is(err, model.ErrPermissionBoundaryNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter limite de permissões: %w", err)
+	}
+	return boundary, nil
+}
+
+// CreateBoundary cria o limite de uma função ou de um administrador delegado
+func (s *PermissionBoundaryServiceImpl) CreateBoundary(ctx context.Context, req *application.SavePermissionBoundaryRequest) (*model.PermissionBoundary, error) {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryServiceImpl.CreateBoundary", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("permission_boundary.subject_type", string(req.SubjectType)),
+		attribute.String("permission_boundary.subject_id", req.SubjectID.String()),
+	))
+	defer span.End()
+
+	now := s.now()
+	boundary := &model.PermissionBoundary{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		SubjectType: req.SubjectType,
+		SubjectID:   req.SubjectID,
+		CreatedBy:   req.ActorID,
+		CreatedAt:   now,
+	}
+	applyPermissionBoundaryRequest(boundary, req, now)
+	boundary.Normalize()
+	if err := boundary.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, boundary); err != nil {
+		if errors.Is(err, model.ErrPermissionBoundaryConflict) || errors.Is(err, model.ErrPermissionBoundarySubjectNotFound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar limite de permissões: %w", err)
+	}
+
+	s.invalidate(boundary.TenantID)
+	logPermissionBoundaryChange(boundary, req.ActorID, "criado")
+	return boundary, nil
+}
+
+// UpdateBoundary altera o nome, a descrição e os padrões de um limite
+func (s *PermissionBoundaryServiceImpl) UpdateBoundary(ctx context.Context, req *application.SavePermissionBoundaryRequest) (*model.PermissionBoundary, error) {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryServiceImpl.UpdateBoundary", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("permission_boundary.id", req.BoundaryID.String()),
+	))
+	defer span.End()
+
+	boundary, err := s.GetBoundary(ctx, req.TenantID, req.BoundaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// O titular identifica o limite nas verificações; um novo titular exige um novo limite
+	if (req.SubjectType != "" && req.SubjectType != boundary.SubjectType) ||
+		(req.SubjectID != uuid.Nil && req.SubjectID != boundary.SubjectID) {
+		return nil, fmt.Errorf("%w: o titular de um limite não pode ser alterado", model.ErrInvalidPermissionBoundary)
+	}
+
+	applyPermissionBoundaryRequest(boundary, req, s.now())
+	boundary.Normalize()
+	if err := boundary.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(ctx, boundary); err != nil {
+		if errors.Is(err, model.ErrPermissionBoundaryNotFound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar limite de permissões: %w", err)
+	}
+
+	s.invalidate(boundary.TenantID)
+	logPermissionBoundaryChange(boundary, req.ActorID, "alterado")
+	return boundary, nil
+}
+
+// DeleteBoundary remove um limite
+func (s *PermissionBoundaryServiceImpl) DeleteBoundary(ctx context.Context, tenantID, boundaryID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryServiceImpl.DeleteBoundary", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("permission_boundary.id", boundaryID.String()),
+	))
+	defer span.End()
+
+	boundary, err := s.GetBoundary(ctx, tenantID, boundaryID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.Delete(ctx, tenantID, boundaryID); err != nil {
+		if errors.Is(err, model.ErrPermissionBoundaryNotFound) {
+			return err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao remover limite de permissões: %w", err)
+	}
+
+	s.invalidate(tenantID)
+	logPermissionBoundaryChange(boundary, actorID, "removido")
+	return nil
+}
+
+// CheckGrant verifica a concessão contra os limites das funções e do administrador
+func (s *PermissionBoundaryServiceImpl) CheckGrant(ctx context.Context, check *application.PermissionBoundaryCheck) error {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryServiceImpl.CheckGrant", trace.WithAttributes(
+		attribute.String("tenant_id", check.TenantID.String()),
+		attribute.String("permission_boundary.operation", string(check.Operation)),
+		attribute.String("role_id", check.RoleID.String()),
+	))
+	defer span.End()
+
+	if len(check.Permissions) == 0 {
+		return nil
+	}
+
+	// As concessões são verificadas contra os limites gravados, e não contra o cache
+	s.invalidate(check.TenantID)
+	cached, err := s.load(ctx, check.TenantID)
+	if err != nil {
+		return err
+	}
+
+	bounded := make(map[uuid.UUID]bool, len(check.BoundedRoleIDs))
+	for _, roleID := range check.BoundedRoleIDs {
+		bounded[roleID] = true
+	}
+
+	var boundaryIDs []uuid.UUID
+	exceeding := make(map[string]bool)
+	for _, boundary := range cached.boundaries {
+		applies := boundary.SubjectType == model.PermissionBoundarySubjectDelegatedAdmin && boundary.SubjectID == check.ActorID ||
+			boundary.SubjectType == model.PermissionBoundarySubjectRole && bounded[boundary.SubjectID]
+		if !applies {
+			continue
+		}
+		if outside := boundary.Exceeding(check.Permissions); len(outside) > 0 {
+			boundaryIDs = append(boundaryIDs, boundary.ID)
+			for _, code := range outside {
+				exceeding[code] = true
+			}
+		}
+	}
+	if len(boundaryIDs) == 0 {
+		return nil
+	}
+
+	permissions := sortedKeys(exceeding)
+	violation := &model.PermissionBoundaryViolation{
+		TenantID:    check.TenantID,
+		Operation:   check.Operation,
+		BoundaryIDs: boundaryIDs,
+		RoleCode:    check.RoleCode,
+		SubjectIDs:  check.SubjectIDs,
+		Permissions: permissions,
+		OccurredAt:  s.now(),
+	}
+	if check.ActorID != uuid.Nil {
+		actorID := check.ActorID
+		violation.ActorID = &actorID
+	}
+	if check.RoleID != uuid.Nil {
+		roleID := check.RoleID
+		violation.RoleID = &roleID
+	}
+
+	// A concessão é recusada mesmo que o registo da violação falhe
+	if _, err := s.RecordViolation(ctx, violation); err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", check.TenantID.String()).
+			Str("operation", string(check.Operation)).
+			Msg("Erro ao registar violação de limite de permissões")
+	}
+
+	span.SetStatus(codes.Error, model.ErrPermissionBoundaryViolation.Error())
+	return fmt.Errorf("%w: %s", model.ErrPermissionBoundaryViolation, strings.Join(permissions, ", "))
+}
+
+// ResolvePolicyBoundaries retorna os limites do usuário e das suas funções, para as políticas
+func (s *PermissionBoundaryServiceImpl) ResolvePolicyBoundaries(ctx context.Context, tenantID, userID uuid.UUID, roles []string) (*model.PermissionBoundaryPolicyInput, error) {
+	cached, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cached.boundaries) == 0 {
+		return nil, nil
+	}
+
+	// As funções do pedido são indexadas sem distinção de maiúsculas, como os códigos das funções
+	held := make(map[string]string, len(roles))
+	for _, role := range roles {
+		held[strings.ToLower(role)] = role
+	}
+
+	input := &model.PermissionBoundaryPolicyInput{Roles: map[string][]string{}}
+	for _, boundary := range cached.boundaries {
+		switch {
+		case boundary.SubjectType == model.PermissionBoundarySubjectDelegatedAdmin && boundary.SubjectID == userID:
+			input.User = append([]string{}, boundary.Permissions...)
+		case boundary.SubjectType == model.PermissionBoundarySubjectRole && boundary.RoleCode != "":
+			if role, ok := held[strings.ToLower(boundary.RoleCode)]; ok {
+				input.Roles[role] = append([]string{}, boundary.Permissions...)
+			}
+		}
+	}
+	return input, nil
+}
+
+// RecordViolation regista a violação como incidente de segurança de severidade alta e publica o evento
+func (s *PermissionBoundaryServiceImpl) RecordViolation(ctx context.Context, violation *model.PermissionBoundaryViolation) (*model.SecurityIncident, error) {
+	incident, err := model.NewPermissionBoundaryIncident(violation, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	logEvent := log.Warn().
+		Str("tenant_id", incident.TenantID.String()).
+		Str("incident_id", incident.ID.String()).
+		Str("rule", string(incident.Rule)).
+		Str("severity", string(incident.Severity)).
+		Str("operation", string(violation.Operation)).
+		Strs("permissions", violation.Permissions)
+	if violation.ActorID != nil {
+		logEvent = logEvent.Str("actor_id", violation.ActorID.String())
+	}
+	logEvent.Msg(incident.Summary)
+
+	if err := s.incidents.Create(ctx, incident); err != nil {
+		return nil, fmt.Errorf("erro ao gravar incidente de segurança: %w", err)
+	}
+
+	if s.publisher != nil {
+		evt := event.NewSecurityAnomalyDetectedEvent(incident)
+		if err := s.publisher.Publish(ctx, evt); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", incident.TenantID.String()).
+				Str("incident_id", incident.ID.String()).
+				Str("event_type", evt.GetType()).
+				Msg("Erro ao publicar evento de violação de limite de permissões")
+		}
+	}
+
+	return incident, nil
+}
+
+// load retorna os limites do tenant em cache, recarregando-os quando o cache expirou
+func (s *PermissionBoundaryServiceImpl) load(ctx context.Context, tenantID uuid.UUID) (cachedPermissionBoundaries, error) {
+	if tenantID == uuid.Nil {
+		return cachedPermissionBoundaries{}, model.ErrInvalidTenantID
+	}
+
+	now := s.now()
+	s.mutex.Lock()
+	cached, ok := s.tenants[tenantID]
+	s.mutex.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	boundaries, err := s.repository.List(ctx, tenantID)
+	if err != nil {
+		return cachedPermissionBoundaries{}, fmt.Errorf("erro ao carregar limites de permissões: %w", err)
+	}
+	if boundaries == nil {
+		boundaries = []*model.PermissionBoundary{}
+	}
+	cached = cachedPermissionBoundaries{
+		boundaries: boundaries,
+		expiresAt:  now.Add(s.cacheTTL),
+	}
+	s.mutex.Lock()
+	s.tenants[tenantID] = cached
+	s.mutex.Unlock()
+	return cached, nil
+}
+
+// invalidate descarta os limites do tenant em cache
+func (s *PermissionBoundaryServiceImpl) invalidate(tenantID uuid.UUID) {
+	s.mutex.Lock()
+	delete(s.tenants, tenantID)
+	s.mutex.Unlock()
+}
+
+// applyPermissionBoundaryRequest copia para o limite os campos alteráveis do pedido
+func applyPermissionBoundaryRequest(boundary *model.PermissionBoundary, req *application.SavePermissionBoundaryRequest, now time.Time) {
+	boundary.Name = req.Name
+	boundary.Description = req.Description
+	boundary.Permissions = req.Permissions
+	boundary.UpdatedBy = req.ActorID
+	boundary.UpdatedAt = now
+}
+
+// logPermissionBoundaryChange regista a criação, alteração ou remoção de um limite de permissões
+func logPermissionBoundaryChange(boundary *model.PermissionBoundary, actorID uuid.UUID, change string) {
+	log.Info().
+		Str("tenant_id", boundary.TenantID.String()).
+		Str("boundary_id", boundary.ID.String()).
+		Str("subject_type", string(boundary.SubjectType)).
+		Str("subject_id", boundary.SubjectID.String()).
+		Strs("permissions", boundary.Permissions).
+		Str("actor_id", actorID.String()).
+		Msgf("Limite de permissões %s", change)
+}
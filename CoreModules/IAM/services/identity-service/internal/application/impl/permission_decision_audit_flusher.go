@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre gravações das decisões de autorização pendentes
+const DefaultPermissionDecisionFlushInterval = 5 * time.Second
+
+// Tempo máximo da gravação final das decisões pendentes no encerramento
+const permissionDecisionFinalFlushTimeout = 10 * time.Second
+
+// PermissionDecisionAuditFlusher grava periodicamente as decisões de autorização pendentes,
+// limitando o atraso dos lotes que não chegam a ficar completos
+type PermissionDecisionAuditFlusher struct {
+	service  application.PermissionDecisionAuditService
+	interval time.Duration
+}
+
+// NewPermissionDecisionAuditFlusher cria o agendador da gravação das decisões
+// Um intervalo não positivo usa DefaultPermissionDecisionFlushInterval
+func NewPermissionDecisionAuditFlusher(service application.PermissionDecisionAuditService, interval time.Duration) *PermissionDecisionAuditFlusher {
+	if interval <= 0 {
+		interval = DefaultPermissionDecisionFlushInterval
+	}
+	return &PermissionDecisionAuditFlusher{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start grava as decisões a cada intervalo até o contexto ser cancelado
+// No cancelamento, as decisões ainda pendentes são gravadas uma última vez
+func (f *PermissionDecisionAuditFlusher) Start(ctx context.Context) {
+	// Os lotes de decisões juntam pedidos de vários tenants
+	ctx = requestctx.WithSystemScope(ctx, "permission_decision_audit_flusher")
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.flush(ctx)
+		case <-ctx.Done():
+			finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), permissionDecisionFinalFlushTimeout)
+			f.flush(finalCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+// flush grava as decisões pendentes e regista as falhas
+func (f *PermissionDecisionAuditFlusher) flush(ctx context.Context) {
+	if err := f.service.Flush(ctx); err != nil {
+		log.Error().Err(err).Msg("Erro ao gravar decisões de autorização")
+	}
+}
@@ -0,0 +1,247 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Valores padrão da auditoria de decisões de autorização
+const (
+	DefaultPermissionDecisionAllowSampleRate = 0.1
+	DefaultPermissionDecisionBatchSize       = 500
+	DefaultPermissionDecisionMaxPending      = 10000
+)
+
+// PermissionDecisionAuditConfig configura a amostragem e a gravação em lote das decisões do PDP
+type PermissionDecisionAuditConfig struct {
+	// Fração das permissões registadas, de 0 (nenhuma) a 1 (todas); as negações são sempre registadas
+	AllowSampleRate float64
+	// Decisões gravadas por operação; atingido este número, a gravação é antecipada
+	BatchSize int
+	// Decisões em memória a partir das quais as permissões amostradas são descartadas
+	MaxPending int
+}
+
+// DefaultPermissionDecisionAuditConfig retorna a configuração padrão da auditoria de decisões
+func DefaultPermissionDecisionAuditConfig() PermissionDecisionAuditConfig {
+	return PermissionDecisionAuditConfig{
+		AllowSampleRate: DefaultPermissionDecisionAllowSampleRate,
+		BatchSize:       DefaultPermissionDecisionBatchSize,
+		MaxPending:      DefaultPermissionDecisionMaxPending,
+	}
+}
+
+// PermissionDecisionAuditServiceImpl implementa a interface PermissionDecisionAuditService
+// As decisões aguardam em memória até serem gravadas em lote no armazenamento de auditoria
+type PermissionDecisionAuditServiceImpl struct {
+	repository repository.PermissionDecisionRepository
+	config     PermissionDecisionAuditConfig
+
+	mutex    sync.Mutex
+	pending  []*model.PermissionDecision
+	flushing bool
+	dropped  int
+
+	// Serializa as gravações para preservar a ordem dos lotes
+	flushMutex sync.Mutex
+
+	random func() float64
+	now    func() time.Time
+}
+
+// NewPermissionDecisionAuditService cria uma nova instância de PermissionDecisionAuditService
+// A taxa de amostragem é limitada ao intervalo [0, 1]; os restantes valores não positivos usam os padrões
+func NewPermissionDecisionAuditService(
+	repo repository.PermissionDecisionRepository,
+	config PermissionDecisionAuditConfig,
+) application.PermissionDecisionAuditService {
+	defaults := DefaultPermissionDecisionAuditConfig()
+	if config.AllowSampleRate < 0 {
+		config.AllowSampleRate = 0
+	}
+	if config.AllowSampleRate > 1 {
+		config.AllowSampleRate = 1
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MaxPending < config.BatchSize {
+		config.MaxPending = defaults.MaxPending
+		if config.MaxPending < config.BatchSize {
+			config.MaxPending = config.BatchSize
+		}
+	}
+
+	return &PermissionDecisionAuditServiceImpl{
+		repository: repo,
+		config:     config,
+		random:     rand.Float64,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Record regista uma decisão de autorização sem bloquear o pedido
+func (s *PermissionDecisionAuditServiceImpl) Record(ctx context.Context, decision *model.PermissionDecision) {
+	if decision == nil {
+		return
+	}
+
+	sampleRate := 1.0
+	if decision.Allowed {
+		sampleRate = s.config.AllowSampleRate
+		if sampleRate <= 0 || s.random() >= sampleRate {
+			return
+		}
+	}
+
+	recorded := *decision
+	if recorded.ID == uuid.Nil {
+		recorded.ID = uuid.New()
+	}
+	if recorded.DecidedAt.IsZero() {
+		recorded.DecidedAt = s.now()
+	}
+	recorded.SampleRate = sampleRate
+
+	s.mutex.Lock()
+	// As negações nunca são descartadas; sob pressão perdem-se apenas permissões amostradas
+	if recorded.Allowed && len(s.pending) >= s.config.MaxPending {
+		s.dropped++
+		s.mutex.Unlock()
+		return
+	}
+	s.pending = append(s.pending, &recorded)
+	startFlush := len(s.pending) >= s.config.BatchSize && !s.flushing
+	if startFlush {
+		s.flushing = true
+	}
+	s.mutex.Unlock()
+
+	if startFlush {
+		go s.flushFullBatches()
+	}
+}
+
+// flushFullBatches grava as decisões pendentes quando um lote fica completo
+func (s *PermissionDecisionAuditServiceImpl) flushFullBatches() {
+	defer func() {
+		s.mutex.Lock()
+		s.flushing = false
+		s.mutex.Unlock()
+	}()
+
+	// Os lotes juntam as decisões de todos os tenants
+	if err := s.Flush(requestctx.WithSystemScope(context.Background(), "permission_decision_audit")); err != nil {
+		log.Error().Err(err).Msg("Erro ao gravar decisões de autorização")
+	}
+}
+
+// Flush grava no armazenamento de auditoria as decisões pendentes no momento da chamada
+// Um lote que falha volta à fila e é gravado na próxima chamada
+func (s *PermissionDecisionAuditServiceImpl) Flush(ctx context.Context) error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	s.mutex.Lock()
+	remaining := len(s.pending)
+	dropped := s.dropped
+	s.dropped = 0
+	s.mutex.Unlock()
+
+	if dropped > 0 {
+		log.Warn().
+			Int("dropped", dropped).
+			Int("max_pending", s.config.MaxPending).
+			Msg("Permissões amostradas descartadas por excesso de decisões pendentes")
+	}
+
+	for remaining > 0 {
+		s.mutex.Lock()
+		size := s.config.BatchSize
+		if size > remaining {
+			size = remaining
+		}
+		if size > len(s.pending) {
+			size = len(s.pending)
+		}
+		batch := append([]*model.PermissionDecision(nil), s.pending[:size]...)
+		s.pending = append([]*model.PermissionDecision(nil), s.pending[size:]...)
+		s.mutex.Unlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := s.repository.AppendBatch(ctx, batch); err != nil {
+			s.requeue(batch)
+			return fmt.Errorf("erro ao gravar lote de %d decisões de autorização: %w", len(batch), err)
+		}
+		remaining -= len(batch)
+	}
+
+	return nil
+}
+
+// requeue devolve um lote não gravado ao início da fila, respeitando o limite de decisões pendentes
+func (s *PermissionDecisionAuditServiceImpl) requeue(batch []*model.PermissionDecision) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	queue := append(batch, s.pending...)
+	excess := len(queue) - s.config.MaxPending
+	if excess > 0 {
+		kept := queue[:0]
+		for _, decision := range queue {
+			if excess > 0 && decision.Allowed {
+				excess--
+				s.dropped++
+				continue
+			}
+			kept = append(kept, decision)
+		}
+		queue = kept
+	}
+	s.pending = queue
+}
+
+// ListDecisions recupera as decisões do tenant que satisfazem o filtro
+func (s *PermissionDecisionAuditServiceImpl) ListDecisions(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	filter model.PermissionDecisionFilter,
+) ([]*model.PermissionDecision, error) {
+	ctx, span := tracer.Start(ctx, "PermissionDecisionAuditServiceImpl.ListDecisions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.resource", filter.Resource),
+	)
+
+	if err := filter.Validate(); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	decisions, err := s.repository.List(ctx, tenantID, filter)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, fmt.Errorf("erro ao consultar decisões de autorização: %w", err)
+	}
+
+	return decisions, nil
+}
@@ -0,0 +1,73 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre execuções dos escalamentos e revogações automáticas das campanhas de recertificação
+const DefaultRecertificationSweepInterval = 15 * time.Minute
+
+// RecertificationScheduler escala periodicamente os itens por decidir das campanhas de recertificação,
+// revoga os itens não decididos no prazo e repete as revogações que falharam
+type RecertificationScheduler struct {
+	service  application.RecertificationService
+	interval time.Duration
+}
+
+// NewRecertificationScheduler cria o agendador das campanhas de recertificação
+// Um intervalo não positivo usa DefaultRecertificationSweepInterval
+func NewRecertificationScheduler(service application.RecertificationService, interval time.Duration) *RecertificationScheduler {
+	if interval <= 0 {
+		interval = DefaultRecertificationSweepInterval
+	}
+	return &RecertificationScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start executa os escalamentos no arranque e a cada intervalo até o contexto ser cancelado
+func (s *RecertificationScheduler) Start(ctx context.Context) {
+	// As escalações abrangem as campanhas de todos os tenants
+	ctx = requestctx.WithSystemScope(ctx, "recertification_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *RecertificationScheduler) run(ctx context.Context) {
+	result, err := s.service.RunScheduledEscalations(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao executar escalamentos automáticos das campanhas de recertificação")
+		return
+	}
+	if result.Escalated == 0 && result.AutoRevoked == 0 && result.CampaignsCompleted == 0 &&
+		result.RevocationsRetried == 0 && result.Failed == 0 {
+		return
+	}
+
+	log.Info().
+		Int("escalated", result.Escalated).
+		Int("auto_revoked", result.AutoRevoked).
+		Int("campaigns_completed", result.CampaignsCompleted).
+		Int("revocations_retried", result.RevocationsRetried).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Escalamentos automáticos das campanhas de recertificação concluídos")
+}
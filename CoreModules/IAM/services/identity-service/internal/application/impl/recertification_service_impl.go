@@ -0,0 +1,724 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das campanhas de recertificação
+const (
+	DefaultRecertificationEscalationLead = 72 * time.Hour
+	DefaultRecertificationSweepBatchSize = 100
+	recertificationRoleUsersPageSize     = 500
+)
+
+// RecertificationConfig configura as campanhas de recertificação
+type RecertificationConfig struct {
+	// Antecedência do escalamento em relação ao prazo, para as campanhas abertas sem data de
+	// escalamento; nas campanhas mais curtas, o escalamento ocorre a meio do prazo
+	EscalationLead time.Duration
+	// Campanhas e revogações processadas por lote nas execuções agendadas
+	SweepBatchSize int
+}
+
+// DefaultRecertificationConfig retorna a configuração padrão das campanhas de recertificação
+func DefaultRecertificationConfig() RecertificationConfig {
+	return RecertificationConfig{
+		EscalationLead: DefaultRecertificationEscalationLead,
+		SweepBatchSize: DefaultRecertificationSweepBatchSize,
+	}
+}
+
+// RecertificationServiceImpl implementa a interface RecertificationService
+type RecertificationServiceImpl struct {
+	repository     repository.RecertificationRepository
+	roles          application.RoleService
+	accessRequests application.AccessRequestService
+	publisher      event.Publisher
+	config         RecertificationConfig
+	now            func() time.Time
+}
+
+// NewRecertificationService cria uma nova instância de RecertificationService
+// Os donos das funções registados como aprovadores dos pedidos de acesso revêem os itens das suas
+// funções; sem accessRequests, todos os itens vão para o revisor padrão da campanha. Sem publisher,
+// os revisores não são notificados. Os valores fora do intervalo válido usam os padrões
+func NewRecertificationService(
+	repo repository.RecertificationRepository,
+	roles application.RoleService,
+	accessRequests application.AccessRequestService,
+	publisher event.Publisher,
+	config RecertificationConfig,
+) application.RecertificationService {
+	defaults := DefaultRecertificationConfig()
+	if config.EscalationLead <= 0 {
+		config.EscalationLead = defaults.EscalationLead
+	}
+	if config.SweepBatchSize <= 0 {
+		config.SweepBatchSize = defaults.SweepBatchSize
+	}
+
+	return &RecertificationServiceImpl{
+		repository:     repo,
+		roles:          roles,
+		accessRequests: accessRequests,
+		publisher:      publisher,
+		config:         config,
+		now:            func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// CreateCampaign abre uma campanha, gera um item por atribuição no âmbito e notifica os revisores
+func (s *RecertificationServiceImpl) CreateCampaign(ctx context.Context, req *application.CreateRecertificationCampaignRequest) (*model.RecertificationCampaign, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationServiceImpl.CreateCampaign", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.Int("roles", len(req.RoleIDs)),
+		attribute.Int("users", len(req.UserIDs)),
+	))
+	defer span.End()
+
+	now := s.now()
+	campaign := &model.RecertificationCampaign{
+		ID:                   uuid.New(),
+		TenantID:             req.TenantID,
+		Name:                 strings.TrimSpace(req.Name),
+		Description:          strings.TrimSpace(req.Description),
+		RoleIDs:              uniqueUUIDs(req.RoleIDs),
+		UserIDs:              uniqueUUIDs(req.UserIDs),
+		DefaultReviewerID:    req.DefaultReviewerID,
+		EscalationReviewerID: req.EscalationReviewerID,
+		Status:               model.RecertificationCampaignActive,
+		Deadline:             req.Deadline.UTC(),
+		CreatedBy:            req.ActorID,
+		CreatedAt:            now,
+	}
+	if req.EscalateAt != nil {
+		campaign.EscalateAt = req.EscalateAt.UTC()
+	} else {
+		campaign.EscalateAt = campaign.Deadline.Add(-s.config.EscalationLead)
+		if !campaign.EscalateAt.After(now) {
+			campaign.EscalateAt = now.Add(campaign.Deadline.Sub(now) / 2)
+		}
+	}
+	if err := campaign.Validate(now); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.roleOwners(ctx, campaign.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*model.RecertificationItem
+	for _, roleID := range campaign.RoleIDs {
+		roleItems, err := s.generateItems(ctx, campaign, roleID, owners[roleID], now)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, roleItems...)
+		if len(items) > model.MaxRecertificationCampaignItems {
+			return nil, fmt.Errorf("%w: o âmbito excede %d atribuições",
+				model.ErrInvalidRecertificationCampaign, model.MaxRecertificationCampaignItems)
+		}
+	}
+	if len(items) == 0 {
+		return nil, model.ErrRecertificationEmptyScope
+	}
+	campaign.ItemCount = len(items)
+
+	if err := s.repository.CreateCampaign(ctx, campaign, items); err != nil {
+		return nil, fmt.Errorf("erro ao gravar campanha de recertificação: %w", err)
+	}
+	span.SetAttributes(attribute.Int("items", len(items)))
+
+	log.Info().
+		Str("tenant_id", campaign.TenantID.String()).
+		Str("campaign_id", campaign.ID.String()).
+		Int("items", campaign.ItemCount).
+		Time("deadline", campaign.Deadline).
+		Str("actor_id", campaign.CreatedBy.String()).
+		Msg("Campanha de recertificação aberta")
+
+	s.notifyReviewers(ctx, campaign, items, false, now)
+	return campaign, nil
+}
+
+// GetCampaign recupera uma campanha do tenant
+func (s *RecertificationServiceImpl) GetCampaign(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationCampaign, error) {
+	campaign, err := s.repository.GetCampaign(ctx, tenantID, campaignID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecertificationCampaignNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao recuperar campanha de recertificação: %w", err)
+	}
+	return campaign, nil
+}
+
+// ListCampaigns recupera as campanhas do tenant; status limita a um estado
+func (s *RecertificationServiceImpl) ListCampaigns(ctx context.Context, tenantID uuid.UUID, status model.RecertificationCampaignStatus) ([]*model.RecertificationCampaign, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: estado desconhecido %q", model.ErrInvalidRecertificationCampaign, status)
+	}
+	campaigns, err := s.repository.ListCampaigns(ctx, tenantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar campanhas de recertificação: %w", err)
+	}
+	return campaigns, nil
+}
+
+// CancelCampaign cancela uma campanha ativa; as decisões já tomadas mantêm-se
+func (s *RecertificationServiceImpl) CancelCampaign(ctx context.Context, tenantID, campaignID, actorID uuid.UUID, reason string) (*model.RecertificationCampaign, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationServiceImpl.CancelCampaign", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("campaign_id", campaignID.String()),
+	))
+	defer span.End()
+
+	campaign, err := s.GetCampaign(ctx, tenantID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	from := campaign.Status
+	if err := campaign.Cancel(actorID, strings.TrimSpace(reason), s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateCampaign(ctx, campaign, from); err != nil {
+		if errors.Is(err, model.ErrRecertificationCampaignState) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar campanha de recertificação: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", campaign.TenantID.String()).
+		Str("campaign_id", campaign.ID.String()).
+		Str("actor_id", actorID.String()).
+		Str("reason", campaign.CancelReason).
+		Msg("Campanha de recertificação cancelada")
+	return campaign, nil
+}
+
+// ListItems recupera os itens de uma campanha que satisfazem o filtro
+func (s *RecertificationServiceImpl) ListItems(ctx context.Context, tenantID, campaignID uuid.UUID, filter model.RecertificationItemFilter) ([]*model.RecertificationItem, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: estado desconhecido %q", model.ErrInvalidRecertificationDecision, filter.Status)
+	}
+	if _, err := s.GetCampaign(ctx, tenantID, campaignID); err != nil {
+		return nil, err
+	}
+
+	filter.CampaignID = &campaignID
+	items, err := s.repository.ListItems(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens de recertificação: %w", err)
+	}
+	return items, nil
+}
+
+// ListPendingReviews recupera os itens por decidir atribuídos ao revisor nas campanhas ativas
+func (s *RecertificationServiceImpl) ListPendingReviews(ctx context.Context, tenantID, reviewerID uuid.UUID) ([]*model.RecertificationItem, error) {
+	campaigns, err := s.repository.ListCampaigns(ctx, tenantID, model.RecertificationCampaignActive)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar campanhas de recertificação: %w", err)
+	}
+	if len(campaigns) == 0 {
+		return []*model.RecertificationItem{}, nil
+	}
+	active := make(map[uuid.UUID]bool, len(campaigns))
+	for _, campaign := range campaigns {
+		active[campaign.ID] = true
+	}
+
+	items, err := s.repository.ListItems(ctx, tenantID, model.RecertificationItemFilter{
+		ReviewerID: &reviewerID,
+		Status:     model.RecertificationItemPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens de recertificação: %w", err)
+	}
+
+	pending := make([]*model.RecertificationItem, 0, len(items))
+	for _, item := range items {
+		if active[item.CampaignID] {
+			pending = append(pending, item)
+		}
+	}
+	return pending, nil
+}
+
+// Decide regista a decisão do revisor; a revogação retira de imediato a atribuição da função
+// e a última decisão conclui a campanha
+func (s *RecertificationServiceImpl) Decide(ctx context.Context, req *application.RecertificationDecisionRequest) (*model.RecertificationItem, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationServiceImpl.Decide", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("item_id", req.ItemID.String()),
+		attribute.String("decision", string(req.Decision)),
+	))
+	defer span.End()
+
+	item, err := s.repository.GetItem(ctx, req.TenantID, req.ItemID)
+	if err != nil {
+		if errors.Is(err, model.ErrRecertificationItemNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao recuperar item de recertificação: %w", err)
+	}
+	campaign, err := s.GetCampaign(ctx, req.TenantID, item.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if campaign.Status != model.RecertificationCampaignActive {
+		return nil, model.ErrRecertificationCampaignState
+	}
+	if campaign.IsOverdue(now) {
+		return nil, fmt.Errorf("%w: o prazo da campanha terminou", model.ErrRecertificationCampaignState)
+	}
+
+	from := item.Status
+	if err := item.Decide(req.ReviewerID, req.Decision, strings.TrimSpace(req.Comment), now); err != nil {
+		return nil, err
+	}
+	if err := s.repository.UpdateItem(ctx, item, from); err != nil {
+		if errors.Is(err, model.ErrRecertificationItemDecided) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar decisão de recertificação: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", item.TenantID.String()).
+		Str("campaign_id", item.CampaignID.String()).
+		Str("item_id", item.ID.String()).
+		Str("user_id", item.UserID.String()).
+		Str("role_id", item.RoleID.String()).
+		Str("reviewer_id", req.ReviewerID.String()).
+		Str("status", string(item.Status)).
+		Msg("Decisão de recertificação registada")
+
+	// A decisão fica gravada mesmo que a revogação falhe; a revogação é repetida pelas execuções agendadas
+	if item.Status == model.RecertificationItemRevoked {
+		s.revoke(ctx, item, req.ReviewerID)
+	}
+
+	if err := s.completeIfDecided(ctx, campaign); err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", campaign.TenantID.String()).
+			Str("campaign_id", campaign.ID.String()).
+			Msg("Erro ao concluir campanha de recertificação")
+	}
+	return item, nil
+}
+
+// GetAttestation gera o relatório de atestação de uma campanha concluída
+func (s *RecertificationServiceImpl) GetAttestation(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationAttestation, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationServiceImpl.GetAttestation", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("campaign_id", campaignID.String()),
+	))
+	defer span.End()
+
+	campaign, err := s.GetCampaign(ctx, tenantID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	return s.attestation(ctx, campaign)
+}
+
+// RunScheduledEscalations escala os itens por decidir na data de escalamento, revoga os itens
+// não decididos no prazo, conclui as campanhas e repete as revogações que falharam
+func (s *RecertificationServiceImpl) RunScheduledEscalations(ctx context.Context) (*application.RecertificationSweepResult, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationServiceImpl.RunScheduledEscalations")
+	defer span.End()
+
+	result := &application.RecertificationSweepResult{StartedAt: s.now()}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		campaigns, err := s.repository.ListDueCampaigns(ctx, result.StartedAt, s.config.SweepBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar campanhas de recertificação a processar: %w", err)
+		}
+
+		failed := 0
+		for _, campaign := range campaigns {
+			if campaign.IsOverdue(result.StartedAt) {
+				err = s.closeOverdue(ctx, campaign, result)
+			} else {
+				err = s.escalate(ctx, campaign, result)
+			}
+			if err != nil {
+				failed++
+				log.Error().Err(err).
+					Str("tenant_id", campaign.TenantID.String()).
+					Str("campaign_id", campaign.ID.String()).
+					Msg("Erro ao processar campanha de recertificação")
+			}
+		}
+		result.Failed += failed
+
+		// Parar quando não há mais campanhas ou quando um lote inteiro falhou, para não repetir
+		// indefinidamente as mesmas
+		if len(campaigns) < s.config.SweepBatchSize || failed == len(campaigns) {
+			break
+		}
+	}
+
+	// As revogações que continuam a falhar são repetidas na execução seguinte
+	items, err := s.repository.ListPendingRevocations(ctx, s.config.SweepBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar revogações de recertificação pendentes: %w", err)
+	}
+	for _, item := range items {
+		revokedBy, err := s.revokedBy(ctx, item)
+		if err == nil && s.revoke(ctx, item, revokedBy) {
+			result.RevocationsRetried++
+			continue
+		}
+		result.Failed++
+	}
+
+	span.SetAttributes(
+		attribute.Int("escalated", result.Escalated),
+		attribute.Int("auto_revoked", result.AutoRevoked),
+		attribute.Int("campaigns_completed", result.CampaignsCompleted),
+		attribute.Int("revocations_retried", result.RevocationsRetried),
+		attribute.Int("failed", result.Failed),
+	)
+	return result, nil
+}
+
+// closeOverdue revoga os itens não decididos de uma campanha cujo prazo terminou e conclui-a
+// As revogações são feitas em nome do autor da campanha
+func (s *RecertificationServiceImpl) closeOverdue(ctx context.Context, campaign *model.RecertificationCampaign, result *application.RecertificationSweepResult) error {
+	items, err := s.repository.ListItems(ctx, campaign.TenantID, model.RecertificationItemFilter{
+		CampaignID: &campaign.ID,
+		Status:     model.RecertificationItemPending,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao listar itens por decidir: %w", err)
+	}
+
+	for _, item := range items {
+		if err := item.AutoRevoke(result.StartedAt); err != nil {
+			continue
+		}
+		if err := s.repository.UpdateItem(ctx, item, model.RecertificationItemPending); err != nil {
+			// Decidido entretanto pelo revisor
+			if errors.Is(err, model.ErrRecertificationItemDecided) {
+				continue
+			}
+			return fmt.Errorf("erro ao gravar revogação automática: %w", err)
+		}
+		result.AutoRevoked++
+		s.revoke(ctx, item, campaign.CreatedBy)
+	}
+
+	completed, err := s.complete(ctx, campaign)
+	if err != nil {
+		return err
+	}
+	if completed {
+		result.CampaignsCompleted++
+	}
+	return nil
+}
+
+// escalate entrega os itens por decidir de uma campanha ao revisor de escalamento e notifica-o
+func (s *RecertificationServiceImpl) escalate(ctx context.Context, campaign *model.RecertificationCampaign, result *application.RecertificationSweepResult) error {
+	items, err := s.repository.ListItems(ctx, campaign.TenantID, model.RecertificationItemFilter{
+		CampaignID: &campaign.ID,
+		Status:     model.RecertificationItemPending,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao listar itens por decidir: %w", err)
+	}
+
+	escalated := make([]*model.RecertificationItem, 0, len(items))
+	for _, item := range items {
+		if item.Escalated {
+			continue
+		}
+		if err := item.Escalate(campaign.EscalationReviewerID, result.StartedAt); err != nil {
+			continue
+		}
+		if err := s.repository.UpdateItem(ctx, item, model.RecertificationItemPending); err != nil {
+			if errors.Is(err, model.ErrRecertificationItemDecided) {
+				continue
+			}
+			return fmt.Errorf("erro ao gravar escalamento do item: %w", err)
+		}
+		escalated = append(escalated, item)
+	}
+
+	escalatedAt := result.StartedAt
+	campaign.EscalatedAt = &escalatedAt
+	if err := s.repository.UpdateCampaign(ctx, campaign, model.RecertificationCampaignActive); err != nil {
+		return fmt.Errorf("erro ao gravar escalamento da campanha: %w", err)
+	}
+	result.Escalated += len(escalated)
+
+	if len(escalated) > 0 {
+		log.Warn().
+			Str("tenant_id", campaign.TenantID.String()).
+			Str("campaign_id", campaign.ID.String()).
+			Int("items", len(escalated)).
+			Str("reviewer_id", campaign.EscalationReviewerID.String()).
+			Msg("Itens de recertificação por decidir escalados")
+		s.notifyReviewers(ctx, campaign, escalated, true, result.StartedAt)
+	}
+	return nil
+}
+
+// completeIfDecided conclui a campanha quando já não há itens por decidir
+func (s *RecertificationServiceImpl) completeIfDecided(ctx context.Context, campaign *model.RecertificationCampaign) error {
+	pending, err := s.repository.CountPendingItems(ctx, campaign.TenantID, campaign.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao contar itens por decidir: %w", err)
+	}
+	if pending > 0 {
+		return nil
+	}
+	_, err = s.complete(ctx, campaign)
+	return err
+}
+
+// complete conclui uma campanha ativa e publica o resumo da atestação
+// Retorna false quando a campanha foi concluída ou cancelada entretanto
+func (s *RecertificationServiceImpl) complete(ctx context.Context, campaign *model.RecertificationCampaign) (bool, error) {
+	if err := campaign.Complete(s.now()); err != nil {
+		return false, nil
+	}
+	if err := s.repository.UpdateCampaign(ctx, campaign, model.RecertificationCampaignActive); err != nil {
+		if errors.Is(err, model.ErrRecertificationCampaignState) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao gravar conclusão da campanha: %w", err)
+	}
+
+	attestation, err := s.attestation(ctx, campaign)
+	if err != nil {
+		return true, err
+	}
+
+	log.Info().
+		Str("tenant_id", campaign.TenantID.String()).
+		Str("campaign_id", campaign.ID.String()).
+		Int("approved", attestation.Summary.Approved).
+		Int("revoked", attestation.Summary.Revoked).
+		Int("auto_revoked", attestation.Summary.AutoRevoked).
+		Str("digest", attestation.Digest).
+		Msg("Campanha de recertificação concluída")
+
+	if s.publisher != nil {
+		evt := event.NewRecertificationCampaignCompletedEvent(attestation)
+		if err := s.publisher.Publish(ctx, evt); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", campaign.TenantID.String()).
+				Str("campaign_id", campaign.ID.String()).
+				Str("event_type", evt.GetType()).
+				Msg("Erro ao publicar conclusão da campanha de recertificação")
+		}
+	}
+	return true, nil
+}
+
+// attestation monta o relatório de atestação com todos os itens da campanha
+func (s *RecertificationServiceImpl) attestation(ctx context.Context, campaign *model.RecertificationCampaign) (*model.RecertificationAttestation, error) {
+	items, err := s.repository.ListItems(ctx, campaign.TenantID, model.RecertificationItemFilter{CampaignID: &campaign.ID})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens de recertificação: %w", err)
+	}
+	return model.NewRecertificationAttestation(campaign, items, s.now())
+}
+
+// revoke retira a atribuição de um item revogado e grava o resultado
+// Uma atribuição já inexistente conta como retirada; as falhas ficam no item para nova tentativa
+func (s *RecertificationServiceImpl) revoke(ctx context.Context, item *model.RecertificationItem, revokedBy uuid.UUID) bool {
+	err := s.roles.RevokeUserFromRole(ctx, item.TenantID, item.RoleID, item.UserID, revokedBy)
+	if err == nil || errors.Is(err, application.ErrUserNotAssigned) {
+		revokedAt := s.now()
+		item.RevokedAt = &revokedAt
+		item.RevocationError = ""
+	} else {
+		item.RevocationError = err.Error()
+		log.Error().Err(err).
+			Str("tenant_id", item.TenantID.String()).
+			Str("item_id", item.ID.String()).
+			Str("user_id", item.UserID.String()).
+			Str("role_id", item.RoleID.String()).
+			Msg("Erro ao revogar atribuição de função recusada na recertificação")
+	}
+
+	if updateErr := s.repository.UpdateItem(ctx, item, model.RecertificationItemRevoked); updateErr != nil {
+		log.Error().Err(updateErr).
+			Str("tenant_id", item.TenantID.String()).
+			Str("item_id", item.ID.String()).
+			Msg("Erro ao gravar revogação do item de recertificação")
+		return false
+	}
+	return item.RevokedAt != nil
+}
+
+// revokedBy retorna o autor da revogação de um item: o revisor ou, nas revogações automáticas,
+// o autor da campanha
+func (s *RecertificationServiceImpl) revokedBy(ctx context.Context, item *model.RecertificationItem) (uuid.UUID, error) {
+	if item.DecidedBy != nil {
+		return *item.DecidedBy, nil
+	}
+	campaign, err := s.GetCampaign(ctx, item.TenantID, item.CampaignID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return campaign.CreatedBy, nil
+}
+
+// roleOwners recupera os donos de cada função registados como aprovadores dos pedidos de acesso
+func (s *RecertificationServiceImpl) roleOwners(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	owners := make(map[uuid.UUID][]uuid.UUID)
+	if s.accessRequests == nil {
+		return owners, nil
+	}
+
+	approvers, err := s.accessRequests.ListApprovers(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar donos das funções: %w", err)
+	}
+	for _, approver := range approvers {
+		if approver.Kind == model.AccessApproverKindRoleOwner && approver.RoleID != nil {
+			owners[*approver.RoleID] = append(owners[*approver.RoleID], approver.UserID)
+		}
+	}
+	return owners, nil
+}
+
+// generateItems cria os itens das atribuições em vigor de uma função para os usuários no âmbito
+func (s *RecertificationServiceImpl) generateItems(
+	ctx context.Context,
+	campaign *model.RecertificationCampaign,
+	roleID uuid.UUID,
+	owners []uuid.UUID,
+	now time.Time,
+) ([]*model.RecertificationItem, error) {
+	role, err := s.roles.GetRole(ctx, campaign.TenantID, roleID)
+	if err != nil {
+		if errors.Is(err, application.ErrRoleNotFound) {
+			return nil, fmt.Errorf("%w: função %s não encontrada", model.ErrInvalidRecertificationCampaign, roleID)
+		}
+		return nil, fmt.Errorf("erro ao recuperar função: %w", err)
+	}
+
+	var items []*model.RecertificationItem
+	for page := 1; ; page++ {
+		assignments, total, err := s.roles.GetRoleUsers(ctx, campaign.TenantID, roleID, true, application.Pagination{
+			Page:     page,
+			PageSize: recertificationRoleUsersPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar titulares da função: %w", err)
+		}
+
+		for _, assignment := range assignments {
+			if !campaign.InScope(assignment.UserID) {
+				continue
+			}
+			items = append(items, &model.RecertificationItem{
+				ID:                  uuid.New(),
+				TenantID:            campaign.TenantID,
+				CampaignID:          campaign.ID,
+				UserID:              assignment.UserID,
+				RoleID:              role.ID,
+				RoleCode:            role.Code,
+				RoleName:            role.Name,
+				AssignedAt:          assignment.AssignedAt,
+				AssignedBy:          assignment.AssignedBy,
+				AssignmentExpiresAt: assignment.ExpiresAt,
+				ReviewerID:          recertificationReviewer(campaign, owners, assignment.UserID),
+				Status:              model.RecertificationItemPending,
+			})
+		}
+
+		if len(assignments) == 0 || int64(page*recertificationRoleUsersPageSize) >= total {
+			break
+		}
+	}
+	return items, nil
+}
+
+// recertificationReviewer escolhe o revisor de uma atribuição: o primeiro dono da função que não
+// seja o titular, senão o revisor padrão ou, quando o titular é o revisor padrão, o de escalamento
+func recertificationReviewer(campaign *model.RecertificationCampaign, owners []uuid.UUID, userID uuid.UUID) uuid.UUID {
+	for _, owner := range owners {
+		if owner != userID {
+			return owner
+		}
+	}
+	if campaign.DefaultReviewerID != userID {
+		return campaign.DefaultReviewerID
+	}
+	return campaign.EscalationReviewerID
+}
+
+// notifyReviewers publica uma notificação por revisor com o número de itens que lhe foram entregues
+func (s *RecertificationServiceImpl) notifyReviewers(ctx context.Context, campaign *model.RecertificationCampaign, items []*model.RecertificationItem, escalated bool, now time.Time) {
+	if s.publisher == nil {
+		return
+	}
+
+	counts := make(map[uuid.UUID]int)
+	for _, item := range items {
+		counts[item.ReviewerID]++
+	}
+	reviewers := make([]uuid.UUID, 0, len(counts))
+	for reviewerID := range counts {
+		reviewers = append(reviewers, reviewerID)
+	}
+	sort.Slice(reviewers, func(a, b int) bool { return reviewers[a].String() < reviewers[b].String() })
+
+	for _, reviewerID := range reviewers {
+		evt := event.NewRecertificationReviewEvent(campaign, reviewerID, counts[reviewerID], escalated, now)
+		if err := s.publisher.Publish(ctx, evt); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", campaign.TenantID.String()).
+				Str("campaign_id", campaign.ID.String()).
+				Str("reviewer_id", reviewerID.String()).
+				Str("event_type", evt.GetType()).
+				Msg("Erro ao notificar revisor da campanha de recertificação")
+		}
+	}
+}
+
+// uniqueUUIDs remove os identificadores repetidos, mantendo a ordem
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,297 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Valores padrão do fluxo das alterações de funções
+const (
+	DefaultRoleChangeMaxSubscriptionsPerTenant = 200
+	DefaultRoleChangeMaxSubscriptionsPerUser   = 10
+	DefaultRoleChangeBufferSize                = 64
+)
+
+// RoleChangeTopics são os tópicos do barramento de eventos entregues às subscriptions
+var RoleChangeTopics = []string{
+	event.TopicRoleCreated,
+	event.TopicRoleUpdated,
+	event.TopicRoleSoftDeleted,
+	event.TopicRoleHardDeleted,
+	event.TopicPermissionsAssignedToRole,
+	event.TopicPermissionsRevokedFromRole,
+	event.TopicRoleAssignedToUsers,
+	event.TopicRoleRevokedFromUsers,
+}
+
+// RoleChangeFeedConfig define as quotas e o tamanho da fila de cada subscription
+// Valores não positivos usam os valores padrão
+type RoleChangeFeedConfig struct {
+	MaxSubscriptionsPerTenant int
+	MaxSubscriptionsPerUser   int
+	BufferSize                int
+}
+
+// DefaultRoleChangeFeedConfig retorna a configuração padrão do fluxo das alterações de funções
+func DefaultRoleChangeFeedConfig() RoleChangeFeedConfig {
+	return RoleChangeFeedConfig{
+		MaxSubscriptionsPerTenant: DefaultRoleChangeMaxSubscriptionsPerTenant,
+		MaxSubscriptionsPerUser:   DefaultRoleChangeMaxSubscriptionsPerUser,
+		BufferSize:                DefaultRoleChangeBufferSize,
+	}
+}
+
+// roleChangeSubscription é uma subscription aberta no fluxo
+type roleChangeSubscription struct {
+	subscriber model.RoleChangeSubscriber
+	filter     model.RoleChangeFilter
+	events     chan *model.RoleChangeNotification
+}
+
+// RoleChangeFeedImpl implementa a interface RoleChangeFeed
+// Uma subscription cuja fila enche é encerrada em vez de perder notificações em silêncio;
+// o cliente volta a subscrever e recarrega o estado das funções
+type RoleChangeFeedImpl struct {
+	config RoleChangeFeedConfig
+
+	mutex         sync.RWMutex
+	nextID        uint64
+	subscriptions map[uint64]*roleChangeSubscription
+	byTenant      map[uuid.UUID]int
+	byUser        map[model.RoleChangeSubscriber]int
+	closed        bool
+
+	bus     event.EventBus
+	handler func(ctx context.Context, evt event.Event) error
+}
+
+// NewRoleChangeFeed cria uma nova instância de RoleChangeFeed
+func NewRoleChangeFeed(config RoleChangeFeedConfig) *RoleChangeFeedImpl {
+	defaults := DefaultRoleChangeFeedConfig()
+	if config.MaxSubscriptionsPerTenant <= 0 {
+		config.MaxSubscriptionsPerTenant = defaults.MaxSubscriptionsPerTenant
+	}
+	if config.MaxSubscriptionsPerUser <= 0 {
+		config.MaxSubscriptionsPerUser = defaults.MaxSubscriptionsPerUser
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+
+	return &RoleChangeFeedImpl{
+		config:        config,
+		subscriptions: make(map[uint64]*roleChangeSubscription),
+		byTenant:      make(map[uuid.UUID]int),
+		byUser:        make(map[model.RoleChangeSubscriber]int),
+	}
+}
+
+// Attach assina no barramento os tópicos das funções; cada evento é entregue às subscriptions
+func (f *RoleChangeFeedImpl) Attach(bus event.EventBus) error {
+	handler := f.HandleEvent
+	for _, topic := range RoleChangeTopics {
+		if err := bus.Subscribe(topic, handler); err != nil {
+			return fmt.Errorf("erro ao assinar o tópico %s: %w", topic, err)
+		}
+	}
+
+	f.mutex.Lock()
+	f.bus = bus
+	f.handler = handler
+	f.mutex.Unlock()
+	return nil
+}
+
+// Close cancela as assinaturas no barramento e fecha todas as subscriptions
+func (f *RoleChangeFeedImpl) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	var firstErr error
+	if f.bus != nil {
+		for _, topic := range RoleChangeTopics {
+			if err := f.bus.Unsubscribe(topic, f.handler); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("erro ao cancelar a assinatura do tópico %s: %w", topic, err)
+			}
+		}
+	}
+	for id := range f.subscriptions {
+		f.removeLocked(id)
+	}
+	return firstErr
+}
+
+// Subscribe abre uma subscription até o contexto terminar
+func (f *RoleChangeFeedImpl) Subscribe(ctx context.Context, subscriber model.RoleChangeSubscriber, filter model.RoleChangeFilter) (<-chan *model.RoleChangeNotification, error) {
+	if subscriber.TenantID == uuid.Nil {
+		return nil, model.ErrInvalidTenantID
+	}
+
+	f.mutex.Lock()
+	if f.closed {
+		f.mutex.Unlock()
+		return nil, application.ErrRoleChangeFeedClosed
+	}
+	if f.byTenant[subscriber.TenantID] >= f.config.MaxSubscriptionsPerTenant {
+		f.mutex.Unlock()
+		return nil, fmt.Errorf("%w: máximo de %d por tenant", application.ErrRoleChangeSubscriptionLimit, f.config.MaxSubscriptionsPerTenant)
+	}
+	if f.byUser[subscriber] >= f.config.MaxSubscriptionsPerUser {
+		f.mutex.Unlock()
+		return nil, fmt.Errorf("%w: máximo de %d por usuário", application.ErrRoleChangeSubscriptionLimit, f.config.MaxSubscriptionsPerUser)
+	}
+
+	f.nextID++
+	id := f.nextID
+	subscription := &roleChangeSubscription{
+		subscriber: subscriber,
+		filter:     filter,
+		events:     make(chan *model.RoleChangeNotification, f.config.BufferSize),
+	}
+	f.subscriptions[id] = subscription
+	f.byTenant[subscriber.TenantID]++
+	f.byUser[subscriber]++
+	f.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mutex.Lock()
+		f.removeLocked(id)
+		f.mutex.Unlock()
+	}()
+
+	return subscription.events, nil
+}
+
+// HandleEvent converte o evento numa notificação e entrega-a às subscriptions cujo filtro a aceita
+func (f *RoleChangeFeedImpl) HandleEvent(ctx context.Context, evt event.Event) error {
+	notification := roleChangeNotification(evt)
+	if notification == nil {
+		return nil
+	}
+
+	var lagging []uint64
+	f.mutex.RLock()
+	for id, subscription := range f.subscriptions {
+		if !subscription.filter.Matches(notification) {
+			continue
+		}
+		select {
+		case subscription.events <- notification:
+		default:
+			lagging = append(lagging, id)
+		}
+	}
+	f.mutex.RUnlock()
+
+	if len(lagging) > 0 {
+		f.mutex.Lock()
+		for _, id := range lagging {
+			if subscription, ok := f.subscriptions[id]; ok {
+				log.Warn().
+					Str("tenant_id", subscription.subscriber.TenantID.String()).
+					Str("user_id", subscription.subscriber.UserID.String()).
+					Msg("Subscription das alterações de funções encerrada por não acompanhar as notificações")
+			}
+			f.removeLocked(id)
+		}
+		f.mutex.Unlock()
+	}
+	return nil
+}
+
+// ActiveSubscriptions retorna o número de subscriptions abertas
+func (f *RoleChangeFeedImpl) ActiveSubscriptions() int {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return len(f.subscriptions)
+}
+
+// removeLocked fecha a subscription e liberta as quotas do subscritor; exige o mutex
+func (f *RoleChangeFeedImpl) removeLocked(id uint64) {
+	subscription, ok := f.subscriptions[id]
+	if !ok {
+		return
+	}
+	delete(f.subscriptions, id)
+	close(subscription.events)
+
+	subscriber := subscription.subscriber
+	if f.byTenant[subscriber.TenantID]--; f.byTenant[subscriber.TenantID] <= 0 {
+		delete(f.byTenant, subscriber.TenantID)
+	}
+	if f.byUser[subscriber]--; f.byUser[subscriber] <= 0 {
+		delete(f.byUser, subscriber)
+	}
+}
+
+// roleChangeNotification converte um evento de domínio das funções numa notificação, ou nil
+func roleChangeNotification(evt event.Event) *model.RoleChangeNotification {
+	notification := &model.RoleChangeNotification{
+		ID:         uuid.New(),
+		OccurredAt: evt.GetTime(),
+	}
+
+	switch e := evt.(type) {
+	case *event.RoleCreatedEvent:
+		isActive := e.IsActive
+		actorID := e.CreatedBy
+		notification.Type = model.RoleChangeCreated
+		notification.RoleCode, notification.RoleName = e.Code, e.Name
+		notification.IsActive = &isActive
+		notification.ActorID = &actorID
+	case *event.RoleUpdatedEvent:
+		isActive := e.IsActive
+		notification.Type = model.RoleChangeUpdated
+		notification.RoleCode, notification.RoleName = e.Code, e.Name
+		notification.IsActive = &isActive
+		notification.ActorID = e.UpdatedBy
+	case *event.RoleSoftDeletedEvent:
+		notification.Type = model.RoleChangeDeleted
+		notification.RoleCode = e.Code
+		notification.ActorID = e.DeletedBy
+	case *event.RoleHardDeletedEvent:
+		notification.Type = model.RoleChangeDeleted
+		notification.RoleCode = e.Code
+		notification.HardDeleted = true
+		notification.ActorID = e.DeletedBy
+	case *event.PermissionsAssignedToRoleEvent:
+		notification.Type = model.RoleChangePermissionsAssigned
+		notification.RoleCode = e.RoleCode
+		notification.PermissionIDs = e.PermissionIDs
+		notification.ActorID = e.AssignedBy
+	case *event.PermissionsRevokedFromRoleEvent:
+		notification.Type = model.RoleChangePermissionsRevoked
+		notification.RoleCode = e.RoleCode
+		notification.PermissionIDs = e.PermissionIDs
+	case *event.RoleAssignedToUsersEvent:
+		notification.Type = model.RoleChangeUsersAssigned
+		notification.RoleCode = e.RoleCode
+		notification.UserIDs = e.UserIDs
+		notification.ActorID = e.AssignedBy
+	case *event.RoleRevokedFromUsersEvent:
+		notification.Type = model.RoleChangeUsersRevoked
+		notification.RoleCode = e.RoleCode
+		notification.UserIDs = e.UserIDs
+	default:
+		return nil
+	}
+
+	roleEvent := evt.(event.RoleEvent)
+	notification.TenantID = roleEvent.GetTenantID()
+	notification.RoleID = roleEvent.GetRoleID()
+	return notification
+}
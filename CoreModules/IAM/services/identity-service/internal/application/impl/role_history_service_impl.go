@@ -0,0 +1,205 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Número de eventos entre snapshots consecutivos de uma função
+const DefaultRoleSnapshotInterval = 50
+
+// Número máximo de tentativas ao registrar eventos concorrentes na mesma função
+const roleHistoryAppendAttempts = 3
+
+// RoleHistoryServiceImpl implementa a interface RoleHistoryService
+type RoleHistoryServiceImpl struct {
+	store            repository.RoleEventStore
+	snapshotInterval int64
+	now              func() time.Time
+}
+
+// NewRoleHistoryService cria uma nova instância de RoleHistoryService
+// Um intervalo de snapshot não positivo usa DefaultRoleSnapshotInterval
+func NewRoleHistoryService(store repository.RoleEventStore, snapshotInterval int) application.RoleHistoryService {
+	if snapshotInterval <= 0 {
+		snapshotInterval = DefaultRoleSnapshotInterval
+	}
+	return &RoleHistoryServiceImpl{
+		store:            store,
+		snapshotInterval: int64(snapshotInterval),
+		now:              func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Record registra um evento no histórico da função
+func (s *RoleHistoryServiceImpl) Record(ctx context.Context, evt *model.RoleHistoryEvent) error {
+	ctx, span := tracer.Start(ctx, "RoleHistoryServiceImpl.Record", trace.WithAttributes(
+		attribute.String("tenant_id", evt.TenantID.String()),
+		attribute.String("role_id", evt.RoleID.String()),
+		attribute.String("event_type", string(evt.Type)),
+	))
+	defer span.End()
+
+	if evt.ID == uuid.Nil {
+		evt.ID = uuid.New()
+	}
+
+	var err error
+	for attempt := 0; attempt < roleHistoryAppendAttempts; attempt++ {
+		var last int64
+		last, err = s.store.LastVersion(ctx, evt.TenantID, evt.RoleID)
+		if err != nil {
+			return fmt.Errorf("erro ao obter versão do histórico da função: %w", err)
+		}
+
+		// O instante é atribuído depois de ler a última versão, em cada tentativa: a versão
+		// seguinte nunca fica com um instante anterior ao da versão que a precede
+		evt.Version = last + 1
+		evt.OccurredAt = s.now()
+		err = s.store.Append(ctx, evt)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, model.ErrRoleHistoryVersionConflict) {
+			return fmt.Errorf("erro ao registrar evento da função: %w", err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao registrar evento da função: %w", err)
+	}
+
+	if evt.Version%s.snapshotInterval == 0 {
+		s.takeSnapshot(ctx, evt)
+	}
+
+	return nil
+}
+
+// takeSnapshot materializa o estado da função na versão do evento registrado
+// Falhas não invalidam o registo: o estado continua reconstruível a partir dos eventos
+func (s *RoleHistoryServiceImpl) takeSnapshot(ctx context.Context, evt *model.RoleHistoryEvent) {
+	state, err := s.reconstruct(ctx, evt.TenantID, evt.RoleID, evt.OccurredAt)
+	if err != nil || state.Version != evt.Version {
+		return
+	}
+
+	_ = s.store.SaveSnapshot(ctx, &model.RoleSnapshot{
+		TenantID: evt.TenantID,
+		RoleID:   evt.RoleID,
+		Version:  state.Version,
+		State:    *state,
+		TakenAt:  state.UpdatedAt,
+	})
+}
+
+// GetRoleHistory recupera todos os eventos da função
+func (s *RoleHistoryServiceImpl) GetRoleHistory(ctx context.Context, tenantID, roleID uuid.UUID) ([]*model.RoleHistoryEvent, error) {
+	ctx, span := tracer.Start(ctx, "RoleHistoryServiceImpl.GetRoleHistory", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("role_id", roleID.String()),
+	))
+	defer span.End()
+
+	events, err := s.store.LoadEvents(ctx, tenantID, roleID, 0, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar histórico da função: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, application.ErrRoleHistoryNotFound
+	}
+
+	return events, nil
+}
+
+// GetRoleAt reconstrói o estado da função no instante indicado
+func (s *RoleHistoryServiceImpl) GetRoleAt(ctx context.Context, tenantID, roleID uuid.UUID, at time.Time) (*model.RoleState, error) {
+	ctx, span := tracer.Start(ctx, "RoleHistoryServiceImpl.GetRoleAt", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("role_id", roleID.String()),
+		attribute.String("at", at.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	state, err := s.reconstruct(ctx, tenantID, roleID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// A função ainda não existia no instante solicitado
+	if state.Version == 0 {
+		return nil, application.ErrRoleHistoryNotFound
+	}
+
+	return state, nil
+}
+
+// DiffRole compara o estado da função entre dois instantes
+func (s *RoleHistoryServiceImpl) DiffRole(ctx context.Context, tenantID, roleID uuid.UUID, from, to time.Time) (*model.RoleStateDiff, error) {
+	ctx, span := tracer.Start(ctx, "RoleHistoryServiceImpl.DiffRole", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("role_id", roleID.String()),
+		attribute.String("from", from.Format(time.RFC3339)),
+		attribute.String("to", to.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	if !from.Before(to) {
+		return nil, application.ErrInvalidHistoryRange
+	}
+
+	// Uma função criada depois de "from" é comparada com o estado vazio
+	before, err := s.reconstruct(ctx, tenantID, roleID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.reconstruct(ctx, tenantID, roleID, to)
+	if err != nil {
+		return nil, err
+	}
+	if after.Version == 0 {
+		return nil, application.ErrRoleHistoryNotFound
+	}
+
+	diff := model.DiffRoleStates(before, after)
+	diff.From = from
+	diff.To = to
+
+	return diff, nil
+}
+
+// reconstruct aplica, sobre o snapshot mais recente, os eventos ocorridos até o instante indicado
+func (s *RoleHistoryServiceImpl) reconstruct(ctx context.Context, tenantID, roleID uuid.UUID, at time.Time) (*model.RoleState, error) {
+	state := model.NewRoleState(tenantID, roleID)
+
+	snapshot, err := s.store.LatestSnapshot(ctx, tenantID, roleID, at)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar snapshot da função: %w", err)
+	}
+	if snapshot != nil {
+		state = snapshot.State.Clone()
+	}
+
+	events, err := s.store.LoadEvents(ctx, tenantID, roleID, state.Version, at)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar eventos da função: %w", err)
+	}
+
+	for _, evt := range events {
+		if err := state.Apply(evt); err != nil {
+			return nil, fmt.Errorf("erro ao reconstruir estado da função: %w", err)
+		}
+	}
+
+	return state, nil
+}
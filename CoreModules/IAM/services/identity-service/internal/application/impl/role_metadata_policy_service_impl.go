@@ -0,0 +1,120 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das políticas dos metadados das funções
+const (
+	DefaultRoleMetadataPolicyCacheTTL = time.Minute
+)
+
+// cachedRoleMetadataPolicies guarda as políticas dos metadados de um tenant
+type cachedRoleMetadataPolicies struct {
+	policies  []*model.RoleMetadataPolicy
+	expiresAt time.Time
+}
+
+// RoleMetadataPolicyServiceImpl implementa a interface RoleMetadataPolicyService
+// As políticas do tenant ficam em cache; as permissões do usuário são verificadas em cada pedido
+type RoleMetadataPolicyServiceImpl struct {
+	repository repository.RoleMetadataPolicyRepository
+	cacheTTL   time.Duration
+	now        func() time.Time
+
+	mutex    sync.Mutex
+	policies map[uuid.UUID]cachedRoleMetadataPolicies
+}
+
+// NewRoleMetadataPolicyService cria uma nova instância de RoleMetadataPolicyService
+// Um tempo de cache não positivo usa o valor padrão
+func NewRoleMetadataPolicyService(repo repository.RoleMetadataPolicyRepository, cacheTTL time.Duration) application.RoleMetadataPolicyService {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultRoleMetadataPolicyCacheTTL
+	}
+
+	return &RoleMetadataPolicyServiceImpl{
+		repository: repo,
+		cacheTTL:   cacheTTL,
+		now:        func() time.Time { return time.Now().UTC() },
+		policies:   make(map[uuid.UUID]cachedRoleMetadataPolicies),
+	}
+}
+
+// ListPolicies lista as chaves protegidas dos metadados do tenant
+func (s *RoleMetadataPolicyServiceImpl) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleMetadataPolicy, error) {
+	if tenantID == uuid.Nil {
+		return nil, model.ErrInvalidTenantID
+	}
+
+	now := s.now()
+	s.mutex.Lock()
+	cached, ok := s.policies[tenantID]
+	s.mutex.Unlock()
+
+	if !ok || !now.Before(cached.expiresAt) {
+		permissionCodes, err := s.repository.ListPolicyPermissions(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar políticas dos metadados das funções: %w", err)
+		}
+		cached = cachedRoleMetadataPolicies{
+			policies:  model.BuildRoleMetadataPolicies(permissionCodes),
+			expiresAt: now.Add(s.cacheTTL),
+		}
+		s.mutex.Lock()
+		s.policies[tenantID] = cached
+		s.mutex.Unlock()
+	}
+
+	policies := make([]*model.RoleMetadataPolicy, len(cached.policies))
+	for i, policy := range cached.policies {
+		copied := *policy
+		policies[i] = &copied
+	}
+	return policies, nil
+}
+
+// Access calcula as chaves protegidas que o usuário pode ler e alterar
+// Sem políticas no tenant não é feita nenhuma consulta às permissões do usuário
+func (s *RoleMetadataPolicyServiceImpl) Access(ctx context.Context, tenantID, userID uuid.UUID) (*model.RoleMetadataAccess, error) {
+	ctx, span := tracer.Start(ctx, "RoleMetadataPolicyServiceImpl.Access", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	policies, err := s.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return model.NewRoleMetadataAccess(nil, nil), nil
+	}
+
+	var permissionCodes []string
+	for _, policy := range policies {
+		permissionCodes = append(permissionCodes, policy.Permissions()...)
+	}
+
+	granted, err := s.repository.ListGrantedPermissions(ctx, tenantID, userID, permissionCodes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar permissões dos metadados das funções: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("role_metadata.policies", len(policies)),
+		attribute.Int("role_metadata.granted", len(granted)),
+	)
+	return model.NewRoleMetadataAccess(policies, granted), nil
+}
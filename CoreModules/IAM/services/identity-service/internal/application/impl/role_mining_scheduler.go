@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre passagens da mineração de funções
+const DefaultRoleMiningInterval = 24 * time.Hour
+
+// RoleMiningScheduler recalcula periodicamente as sugestões de função de todos os tenants
+type RoleMiningScheduler struct {
+	service  application.RoleMiningService
+	interval time.Duration
+}
+
+// NewRoleMiningScheduler cria o agendador da mineração de funções
+// Um intervalo não positivo usa DefaultRoleMiningInterval
+func NewRoleMiningScheduler(service application.RoleMiningService, interval time.Duration) *RoleMiningScheduler {
+	if interval <= 0 {
+		interval = DefaultRoleMiningInterval
+	}
+	return &RoleMiningScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start recalcula as sugestões no arranque e a cada intervalo até o contexto ser cancelado
+func (s *RoleMiningScheduler) Start(ctx context.Context) {
+	// A mineração analisa as permissões diretas de todos os tenants
+	ctx = requestctx.WithSystemScope(ctx, "role_mining_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *RoleMiningScheduler) run(ctx context.Context) {
+	result, err := s.service.MineAll(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao recalcular sugestões de função")
+		return
+	}
+
+	log.Info().
+		Int("tenants", result.Tenants).
+		Int("suggestions", result.Suggestions).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Passagem de mineração de funções concluída")
+}
@@ -0,0 +1,313 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da mineração de funções
+const (
+	DefaultRoleMiningMinUsers       = 5
+	DefaultRoleMiningMinPermissions = 2
+	DefaultRoleMiningMinSimilarity  = 0.6
+	DefaultRoleMiningMaxSuggestions = 20
+)
+
+// RoleMiningConfig configura o agrupamento dos usuários e a seleção das sugestões de função
+type RoleMiningConfig struct {
+	// Número mínimo de usuários que devem partilhar as permissões de uma sugestão
+	MinUsers int
+	// Número mínimo de permissões de uma sugestão; sugestões com uma permissão não reduzem atribuições
+	MinPermissions int
+	// Semelhança (Jaccard, de 0 a 1) mínima para juntar os usuários no mesmo grupo
+	MinSimilarity float64
+	// Número máximo de sugestões pendentes por tenant
+	MaxSuggestions int
+}
+
+// DefaultRoleMiningConfig retorna a configuração padrão da mineração de funções
+func DefaultRoleMiningConfig() RoleMiningConfig {
+	return RoleMiningConfig{
+		MinUsers:       DefaultRoleMiningMinUsers,
+		MinPermissions: DefaultRoleMiningMinPermissions,
+		MinSimilarity:  DefaultRoleMiningMinSimilarity,
+		MaxSuggestions: DefaultRoleMiningMaxSuggestions,
+	}
+}
+
+// RoleMiningServiceImpl implementa a interface RoleMiningService
+type RoleMiningServiceImpl struct {
+	repository  repository.RoleMiningRepository
+	roleService application.RoleService
+	config      RoleMiningConfig
+	now         func() time.Time
+}
+
+// NewRoleMiningService cria uma nova instância de RoleMiningService
+// As funções aceites são criadas e recebem permissões e usuários através do RoleService;
+// os valores fora do intervalo válido usam os padrões
+func NewRoleMiningService(
+	repo repository.RoleMiningRepository,
+	roleService application.RoleService,
+	config RoleMiningConfig,
+) application.RoleMiningService {
+	defaults := DefaultRoleMiningConfig()
+	if config.MinUsers < 2 {
+		config.MinUsers = defaults.MinUsers
+	}
+	if config.MinPermissions < 2 {
+		config.MinPermissions = defaults.MinPermissions
+	}
+	if config.MinSimilarity <= 0 || config.MinSimilarity > 1 {
+		config.MinSimilarity = defaults.MinSimilarity
+	}
+	if config.MaxSuggestions <= 0 {
+		config.MaxSuggestions = defaults.MaxSuggestions
+	}
+
+	return &RoleMiningServiceImpl{
+		repository:  repo,
+		roleService: roleService,
+		config:      config,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// MineTenant recalcula as sugestões pendentes do tenant
+func (s *RoleMiningServiceImpl) MineTenant(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningServiceImpl.MineTenant", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	assignments, err := s.repository.ListDirectAssignments(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar permissões diretas: %w", err)
+	}
+
+	reviewed, err := s.repository.ListSuggestions(ctx, tenantID, "")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar sugestões de função: %w", err)
+	}
+	skip := make(map[string]bool)
+	for _, suggestion := range reviewed {
+		if suggestion.Status != model.RoleSuggestionStatusPending {
+			skip[suggestion.Fingerprint] = true
+		}
+	}
+
+	// Minerar sem limite e aplicar o limite depois de excluir os conjuntos já revistos
+	mined := model.MineRoleSuggestions(tenantID, assignments, model.RoleMiningParams{
+		MinUsers:       s.config.MinUsers,
+		MinPermissions: s.config.MinPermissions,
+		MinSimilarity:  s.config.MinSimilarity,
+	}, s.now())
+	suggestions := make([]*model.RoleSuggestion, 0, s.config.MaxSuggestions)
+	for _, suggestion := range mined {
+		if skip[suggestion.Fingerprint] {
+			continue
+		}
+		if len(suggestions) == s.config.MaxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := s.repository.ReplacePendingSuggestions(ctx, tenantID, suggestions); err != nil {
+		return nil, fmt.Errorf("erro ao gravar sugestões de função: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("assignments", len(assignments)),
+		attribute.Int("suggestions", len(suggestions)),
+	)
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Int("assignments", len(assignments)).
+		Int("suggestions", len(suggestions)).
+		Msg("Sugestões de função recalculadas")
+
+	return suggestions, nil
+}
+
+// MineAll recalcula as sugestões de todos os tenants com permissões diretas
+// A falha num tenant é registada e não interrompe os restantes
+func (s *RoleMiningServiceImpl) MineAll(ctx context.Context) (*application.RoleMiningRunResult, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningServiceImpl.MineAll")
+	defer span.End()
+
+	result := &application.RoleMiningRunResult{StartedAt: s.now()}
+	tenants, err := s.repository.ListTenantsWithDirectPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tenants com permissões diretas: %w", err)
+	}
+
+	for _, tenantID := range tenants {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		suggestions, err := s.MineTenant(ctx, tenantID)
+		if err != nil {
+			result.Failed++
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Msg("Erro ao recalcular sugestões de função do tenant")
+			continue
+		}
+		result.Tenants++
+		result.Suggestions += len(suggestions)
+	}
+
+	return result, nil
+}
+
+// ListSuggestions recupera as sugestões do tenant no estado indicado
+func (s *RoleMiningServiceImpl) ListSuggestions(ctx context.Context, tenantID uuid.UUID, status string) ([]*model.RoleSuggestion, error) {
+	switch status {
+	case "", model.RoleSuggestionStatusPending, model.RoleSuggestionStatusAccepted, model.RoleSuggestionStatusDismissed:
+	default:
+		return nil, fmt.Errorf("%w: estado %q desconhecido", model.ErrInvalidRoleSuggestion, status)
+	}
+
+	suggestions, err := s.repository.ListSuggestions(ctx, tenantID, status)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar sugestões de função: %w", err)
+	}
+	return suggestions, nil
+}
+
+// GetSuggestion recupera uma sugestão do tenant
+func (s *RoleMiningServiceImpl) GetSuggestion(ctx context.Context, tenantID, suggestionID uuid.UUID) (*model.RoleSuggestion, error) {
+	suggestion, err := s.repository.GetSuggestion(ctx, tenantID, suggestionID)
+	if err != nil {
+		if errors.Is(err, model.ErrRoleSuggestionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter sugestão de função: %w", err)
+	}
+	return suggestion, nil
+}
+
+// AcceptSuggestion cria a função com as permissões da sugestão e marca-a como aceite
+// A função é criada antes da revisão ser gravada; uma aceitação concorrente falha na criação
+// da função com o mesmo código ou na gravação da revisão
+func (s *RoleMiningServiceImpl) AcceptSuggestion(ctx context.Context, req *application.AcceptRoleSuggestionRequest) (*application.RoleSuggestionAcceptance, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningServiceImpl.AcceptSuggestion", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("suggestion_id", req.SuggestionID.String()),
+		attribute.Bool("assign_users", req.AssignUsers),
+	))
+	defer span.End()
+
+	if strings.TrimSpace(req.RoleCode) == "" || strings.TrimSpace(req.RoleName) == "" {
+		return nil, fmt.Errorf("%w: código e nome da função obrigatórios", model.ErrInvalidRoleSuggestion)
+	}
+
+	suggestion, err := s.GetSuggestion(ctx, req.TenantID, req.SuggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion.Status != model.RoleSuggestionStatusPending {
+		return nil, model.ErrRoleSuggestionNotPending
+	}
+
+	role, err := s.roleService.CreateRole(ctx, application.CreateRoleRequest{
+		TenantID:    req.TenantID,
+		Code:        strings.TrimSpace(req.RoleCode),
+		Name:        strings.TrimSpace(req.RoleName),
+		Description: req.Description,
+		Type:        string(model.RoleTypeCustom),
+		CreatedBy:   req.AcceptedBy,
+		Metadata: map[string]interface{}{
+			model.RoleMetadataSuggestionID: suggestion.ID.String(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permissionID := range suggestion.PermissionIDs {
+		if err := s.roleService.AssignPermission(ctx, req.TenantID, role.ID, permissionID, req.AcceptedBy); err != nil {
+			return nil, fmt.Errorf("erro ao atribuir a permissão %s à função sugerida: %w", permissionID, err)
+		}
+	}
+
+	assigned := 0
+	if req.AssignUsers {
+		activatesAt := s.now()
+		for _, userID := range suggestion.UserIDs {
+			if err := s.roleService.AssignUserToRole(ctx, req.TenantID, role.ID, userID, activatesAt, nil, req.AcceptedBy); err != nil {
+				return nil, fmt.Errorf("erro ao atribuir a função sugerida ao usuário %s: %w", userID, err)
+			}
+			assigned++
+		}
+	}
+
+	if err := suggestion.Review(model.RoleSuggestionStatusAccepted, req.AcceptedBy, &role.ID, s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.SaveReview(ctx, suggestion); err != nil {
+		if errors.Is(err, model.ErrRoleSuggestionNotPending) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar aceitação da sugestão de função: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", req.TenantID.String()).
+		Str("suggestion_id", suggestion.ID.String()).
+		Str("role_id", role.ID.String()).
+		Int("permissions", len(suggestion.PermissionIDs)).
+		Int("assigned_users", assigned).
+		Str("accepted_by", req.AcceptedBy.String()).
+		Msg("Sugestão de função aceite")
+
+	return &application.RoleSuggestionAcceptance{
+		Suggestion:    suggestion,
+		Role:          role,
+		AssignedUsers: assigned,
+	}, nil
+}
+
+// DismissSuggestion descarta a sugestão; o mesmo conjunto de permissões não volta a ser sugerido
+func (s *RoleMiningServiceImpl) DismissSuggestion(ctx context.Context, tenantID, suggestionID, dismissedBy uuid.UUID) (*model.RoleSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningServiceImpl.DismissSuggestion", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("suggestion_id", suggestionID.String()),
+	))
+	defer span.End()
+
+	suggestion, err := s.GetSuggestion(ctx, tenantID, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := suggestion.Review(model.RoleSuggestionStatusDismissed, dismissedBy, nil, s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.repository.SaveReview(ctx, suggestion); err != nil {
+		if errors.Is(err, model.ErrRoleSuggestionNotPending) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar descarte da sugestão de função: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("suggestion_id", suggestion.ID.String()).
+		Str("dismissed_by", dismissedBy.String()).
+		Msg("Sugestão de função descartada")
+
+	return suggestion, nil
+}
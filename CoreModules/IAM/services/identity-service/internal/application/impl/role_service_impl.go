@@ -2,6 +2,7 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"innovabiz/iam/identity-service/internal/domain/event"
 	"innovabiz/iam/identity-service/internal/domain/model"
 	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
 )
 
 var tracer = otel.Tracer("innovabiz.iam.application.role")
@@ -25,6 +27,9 @@ type RoleServiceImpl struct {
 	roleRepository       repository.RoleRepository
 	permissionRepository repository.PermissionRepository
 	eventPublisher       event.Publisher
+	historyService       application.RoleHistoryService
+	transactions         repository.TransactionManager
+	boundaryService      application.PermissionBoundaryService
 }
 
 // NewRoleService cria uma nova instância de RoleService
@@ -40,6 +45,18 @@ func NewRoleService(
 	}
 }
 
+// SetHistoryService configura o serviço de histórico que regista as alterações das funções,
+// gravadas na mesma transação que a alteração
+func (r *RoleServiceImpl) SetHistoryService(historyService application.RoleHistoryService, transactions repository.TransactionManager) {
+	r.historyService = historyService
+	r.transactions = transactions
+}
+
+// SetBoundaryService configura o serviço de limites de permissões que verifica as atribuições
+func (r *RoleServiceImpl) SetBoundaryService(boundaryService application.PermissionBoundaryService) {
+	r.boundaryService = boundaryService
+}
+
 // CreateRole cria uma nova função no sistema
 func (r *RoleServiceImpl) CreateRole(ctx context.Context, req application.CreateRoleRequest) (*model.Role, error) {
 	ctx, span := tracer.Start(ctx, "RoleServiceImpl.CreateRole", trace.WithAttributes(
@@ -73,25 +90,25 @@ func (r *RoleServiceImpl) CreateRole(ctx context.Context, req application.Create
 		return nil, fmt.Errorf("erro ao criar modelo de função: %w", err)
 	}
 
-	// Persistir no repositório
-	err = r.roleRepository.Create(ctx, role)
+	// Persistir no repositório, com a marcação de sistema e o histórico na mesma transação
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		if err := r.roleRepository.Create(ctx, role); err != nil {
+			return fmt.Errorf("erro ao persistir função: %w", err)
+		}
+		if req.IsSystem {
+			role.MarkAsSystem()
+			if err := r.roleRepository.Update(ctx, role); err != nil {
+				return fmt.Errorf("erro ao marcar função como do sistema: %w", err)
+			}
+		}
+		return r.recordRoleHistory(ctx, role, model.RoleHistoryCreated, roleHistoryAttributes(role), nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao persistir função: %w", err)
+		return nil, err
 	}
 
 	// Se a função for marcada como do sistema, sincronizar permissões do sistema
 	if req.IsSystem {
-		role.MarkAsSystem()
-		err = r.roleRepository.Update(ctx, role)
-		if err != nil {
-			log.Error().Err(err).
-				Str("tenant_id", req.TenantID.String()).
-				Str("role_id", role.ID().String()).
-				Str("role_code", role.Code()).
-				Msg("Erro ao marcar função como do sistema após criação")
-			// Não retornamos o erro aqui, pois a função já foi criada com sucesso
-		}
-
 		// Sincronizar permissões para funções do sistema
 		if req.SyncSystemPermissions {
 			err = r.syncRolePermissions(ctx, role, req.PermissionCodes)
@@ -200,9 +217,14 @@ func (r *RoleServiceImpl) UpdateRole(ctx context.Context, req application.Update
 
 	// Se houve alterações, persistir no repositório
 	if updated {
-		err = r.roleRepository.Update(ctx, role)
+		err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+			if err := r.roleRepository.Update(ctx, role); err != nil {
+				return fmt.Errorf("erro ao persistir atualização da função: %w", err)
+			}
+			return r.recordRoleHistory(ctx, role, model.RoleHistoryUpdated, roleHistoryAttributes(role), nil)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("erro ao persistir atualização da função: %w", err)
+			return nil, err
 		}
 
 		// Publicar evento de atualização de função
@@ -265,15 +287,21 @@ func (r *RoleServiceImpl) DeleteRole(ctx context.Context, req application.Delete
 		return application.ErrRoleHasUsers
 	}
 
-	// Executar a exclusão com base no tipo solicitado
-	if req.HardDelete {
-		err = r.roleRepository.HardDelete(ctx, req.TenantID, req.ID)
-	} else {
-		err = r.roleRepository.SoftDelete(ctx, req.TenantID, req.ID, req.DeletedBy)
-	}
-
+	// Executar a exclusão com base no tipo solicitado, com o histórico na mesma transação
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		var err error
+		if req.HardDelete {
+			err = r.roleRepository.HardDelete(ctx, req.TenantID, req.ID)
+		} else {
+			err = r.roleRepository.SoftDelete(ctx, req.TenantID, req.ID, req.DeletedBy)
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao excluir função: %w", err)
+		}
+		return r.recordRoleHistory(ctx, role, model.RoleHistoryDeleted, model.RoleHistoryPayload{}, nil)
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao excluir função: %w", err)
+		return err
 	}
 
 	// Publicar evento de exclusão de função
@@ -416,10 +444,36 @@ func (r *RoleServiceImpl) AssignPermission(ctx context.Context, req application.
 		return application.ErrPermissionAlreadyAssigned
 	}
 
-	// Atribuir a permissão
-	err = r.roleRepository.AssignPermission(ctx, req.TenantID, req.RoleID, req.PermissionID, req.CreatedBy)
+	// Verificar os limites da função, das descendentes que herdam a permissão e do administrador
+	boundedRoleIDs, err := r.boundedRoleIDs(ctx, req.TenantID, role.ID())
 	if err != nil {
-		return fmt.Errorf("erro ao atribuir permissão à função: %w", err)
+		return err
+	}
+	err = r.checkPermissionBoundaries(ctx, &application.PermissionBoundaryCheck{
+		TenantID:       req.TenantID,
+		ActorID:        req.CreatedBy,
+		Operation:      model.PermissionBoundaryOperationAssignPermission,
+		RoleID:         role.ID(),
+		RoleCode:       role.Code(),
+		BoundedRoleIDs: boundedRoleIDs,
+		SubjectIDs:     []uuid.UUID{permission.ID()},
+		Permissions:    []string{permission.Code()},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Atribuir a permissão, com o histórico na mesma transação
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		if err := r.roleRepository.AssignPermission(ctx, req.TenantID, req.RoleID, req.PermissionID, req.CreatedBy); err != nil {
+			return fmt.Errorf("erro ao atribuir permissão à função: %w", err)
+		}
+		return r.recordRoleHistory(ctx, role, model.RoleHistoryPermissionsAssigned, model.RoleHistoryPayload{
+			PermissionIDs: []uuid.UUID{permission.ID()},
+		}, &req.CreatedBy)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Publicar evento de atribuição de permissão
@@ -465,10 +519,17 @@ func (r *RoleServiceImpl) RevokePermission(ctx context.Context, req application.
 		return application.ErrPermissionNotAssigned
 	}
 
-	// Revogar a permissão
-	err = r.roleRepository.RevokePermission(ctx, req.TenantID, req.RoleID, req.PermissionID)
+	// Revogar a permissão, com o histórico na mesma transação
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		if err := r.roleRepository.RevokePermission(ctx, req.TenantID, req.RoleID, req.PermissionID); err != nil {
+			return fmt.Errorf("erro ao revogar permissão da função: %w", err)
+		}
+		return r.recordRoleHistory(ctx, role, model.RoleHistoryPermissionsRevoked, model.RoleHistoryPayload{
+			PermissionIDs: []uuid.UUID{permission.ID()},
+		}, nil)
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao revogar permissão da função: %w", err)
+		return err
 	}
 
 	// Publicar evento de revogação de permissão
@@ -537,8 +598,16 @@ func (r *RoleServiceImpl) syncRolePermissions(ctx context.Context, role *model.R
 				continue
 			}
 
-			// Atribuir permissão
-			err = r.roleRepository.AssignPermission(ctx, role.TenantID(), role.ID(), permission.ID(), role.CreatedBy())
+			// Atribuir permissão, com o histórico na mesma transação
+			createdBy := role.CreatedBy()
+			err = r.withinTransaction(ctx, role.TenantID(), func(ctx context.Context) error {
+				if err := r.roleRepository.AssignPermission(ctx, role.TenantID(), role.ID(), permission.ID(), createdBy); err != nil {
+					return err
+				}
+				return r.recordRoleHistory(ctx, role, model.RoleHistoryPermissionsAssigned, model.RoleHistoryPayload{
+					PermissionIDs: []uuid.UUID{permission.ID()},
+				}, &createdBy)
+			})
 			if err != nil {
 				log.Error().Err(err).
 					Str("tenant_id", role.TenantID().String()).
@@ -557,7 +626,14 @@ func (r *RoleServiceImpl) syncRolePermissions(ctx context.Context, role *model.R
 	// Remover permissões que não estão na lista
 	if len(permissionsToRemove) > 0 {
 		for _, perm := range permissionsToRemove {
-			err = r.roleRepository.RevokePermission(ctx, role.TenantID(), role.ID(), perm.ID())
+			err = r.withinTransaction(ctx, role.TenantID(), func(ctx context.Context) error {
+				if err := r.roleRepository.RevokePermission(ctx, role.TenantID(), role.ID(), perm.ID()); err != nil {
+					return err
+				}
+				return r.recordRoleHistory(ctx, role, model.RoleHistoryPermissionsRevoked, model.RoleHistoryPayload{
+					PermissionIDs: []uuid.UUID{perm.ID()},
+				}, nil)
+			})
 			if err != nil {
 				log.Error().Err(err).
 					Str("tenant_id", role.TenantID().String()).
@@ -626,10 +702,41 @@ func (r *RoleServiceImpl) AssignChildRole(ctx context.Context, req application.A
 		return application.ErrCyclicRoleHierarchy
 	}
 
-	// Adicionar relação
-	err = r.roleRepository.AddChildRole(ctx, req.TenantID, req.ParentID, req.ChildID, req.CreatedBy)
+	// A função filha e as suas descendentes passam a herdar as permissões da função pai e das ancestrais
+	if r.boundaryService != nil {
+		inherited, err := r.effectivePermissionCodes(ctx, req.TenantID, parentRole.ID())
+		if err != nil {
+			return err
+		}
+		boundedRoleIDs, err := r.boundedRoleIDs(ctx, req.TenantID, childRole.ID())
+		if err != nil {
+			return err
+		}
+		err = r.checkPermissionBoundaries(ctx, &application.PermissionBoundaryCheck{
+			TenantID:       req.TenantID,
+			ActorID:        req.CreatedBy,
+			Operation:      model.PermissionBoundaryOperationAssignChildRole,
+			RoleID:         childRole.ID(),
+			RoleCode:       childRole.Code(),
+			BoundedRoleIDs: boundedRoleIDs,
+			SubjectIDs:     []uuid.UUID{parentRole.ID()},
+			Permissions:    inherited,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Adicionar relação, com o histórico da função filha na mesma transação
+	parentID := parentRole.ID()
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		if err := r.roleRepository.AddChildRole(ctx, req.TenantID, req.ParentID, req.ChildID, req.CreatedBy); err != nil {
+			return fmt.Errorf("erro ao adicionar função filha: %w", err)
+		}
+		return r.recordRoleHistory(ctx, childRole, model.RoleHistoryParentAssigned, model.RoleHistoryPayload{ParentID: &parentID}, &req.CreatedBy)
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao adicionar função filha: %w", err)
+		return err
 	}
 
 	// Publicar evento de adição de função filha
@@ -674,10 +781,16 @@ func (r *RoleServiceImpl) RemoveChildRole(ctx context.Context, req application.R
 		return application.ErrChildRoleNotAssigned
 	}
 
-	// Remover relação
-	err = r.roleRepository.RemoveChildRole(ctx, req.TenantID, req.ParentID, req.ChildID)
+	// Remover relação, com o histórico da função filha na mesma transação
+	parentID := parentRole.ID()
+	err = r.withinTransaction(ctx, req.TenantID, func(ctx context.Context) error {
+		if err := r.roleRepository.RemoveChildRole(ctx, req.TenantID, req.ParentID, req.ChildID); err != nil {
+			return fmt.Errorf("erro ao remover função filha: %w", err)
+		}
+		return r.recordRoleHistory(ctx, childRole, model.RoleHistoryParentRemoved, model.RoleHistoryPayload{ParentID: &parentID}, nil)
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao remover função filha: %w", err)
+		return err
 	}
 
 	// Publicar evento de remoção de função filha
@@ -827,6 +940,26 @@ func (r *RoleServiceImpl) AssignUserToRole(ctx context.Context, req application.
 		return application.ErrUserAlreadyAssigned
 	}
 
+	// O administrador só pode atribuir funções cujas permissões estão dentro do seu limite
+	if r.boundaryService != nil {
+		granted, err := r.effectivePermissionCodes(ctx, req.TenantID, role.ID())
+		if err != nil {
+			return err
+		}
+		err = r.checkPermissionBoundaries(ctx, &application.PermissionBoundaryCheck{
+			TenantID:    req.TenantID,
+			ActorID:     req.CreatedBy,
+			Operation:   model.PermissionBoundaryOperationAssignUser,
+			RoleID:      role.ID(),
+			RoleCode:    role.Code(),
+			SubjectIDs:  []uuid.UUID{req.UserID},
+			Permissions: granted,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Preparar datas de ativação e expiração
 	activatesAt := req.ActivatesAt
 	if activatesAt.IsZero() {
@@ -1154,6 +1287,118 @@ func (r *RoleServiceImpl) syncRoleHierarchy(ctx context.Context, tenantID uuid.U
 	return nil
 }// Métodos para publicação de eventos
 
+// roleHistoryAttributes extrai os atributos da função registados no histórico
+func roleHistoryAttributes(role *model.Role) model.RoleHistoryPayload {
+	code, name, description := role.Code(), role.Name(), role.Description()
+	roleType := model.RoleType(role.Type())
+	isActive := role.IsActive()
+
+	return model.RoleHistoryPayload{
+		Code:        &code,
+		Name:        &name,
+		Description: &description,
+		Type:        &roleType,
+		IsActive:    &isActive,
+	}
+}
+
+// checkPermissionBoundaries verifica a concessão contra os limites de permissões, quando configurados
+func (r *RoleServiceImpl) checkPermissionBoundaries(ctx context.Context, check *application.PermissionBoundaryCheck) error {
+	if r.boundaryService == nil {
+		return nil
+	}
+
+	err := r.boundaryService.CheckGrant(ctx, check)
+	if err != nil {
+		if errors.Is(err, application.ErrPermissionBoundaryViolation) {
+			return err
+		}
+		return fmt.Errorf("erro ao verificar limites de permissões: %w", err)
+	}
+	return nil
+}
+
+// boundedRoleIDs retorna a função e as descendentes, que herdam as permissões atribuídas à função
+func (r *RoleServiceImpl) boundedRoleIDs(ctx context.Context, tenantID, roleID uuid.UUID) ([]uuid.UUID, error) {
+	if r.boundaryService == nil {
+		return nil, nil
+	}
+
+	descendants, err := r.roleRepository.GetDescendantRoles(ctx, tenantID, roleID, 10)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar funções descendentes: %w", err)
+	}
+
+	roleIDs := []uuid.UUID{roleID}
+	for _, descendant := range descendants {
+		roleIDs = append(roleIDs, descendant.ID())
+	}
+	return roleIDs, nil
+}
+
+// effectivePermissionCodes retorna os códigos das permissões da função e das ancestrais, que a função herda
+func (r *RoleServiceImpl) effectivePermissionCodes(ctx context.Context, tenantID, roleID uuid.UUID) ([]string, error) {
+	ancestors, err := r.roleRepository.GetAncestorRoles(ctx, tenantID, roleID, 10)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar funções ancestrais: %w", err)
+	}
+
+	roleIDs := []uuid.UUID{roleID}
+	for _, ancestor := range ancestors {
+		roleIDs = append(roleIDs, ancestor.ID())
+	}
+
+	codes := make(map[string]bool)
+	for _, id := range roleIDs {
+		permissions, err := r.roleRepository.GetPermissions(ctx, tenantID, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar permissões da função: %w", err)
+		}
+		for _, permission := range permissions {
+			codes[permission.Code()] = true
+		}
+	}
+	return sortedKeys(codes), nil
+}
+
+// withinTransaction executa a alteração da função e o registo no histórico na mesma transação,
+// no tenant da função
+func (r *RoleServiceImpl) withinTransaction(ctx context.Context, tenantID uuid.UUID, fn func(ctx context.Context) error) error {
+	if r.transactions == nil {
+		return fn(ctx)
+	}
+	ctx, err := requestctx.EnsureTenant(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação da função: %w", err)
+	}
+	return r.transactions.WithinTransaction(ctx, fn)
+}
+
+// recordRoleHistory regista a alteração da função no histórico imutável, na transação do contexto
+// A falha do registo reverte a alteração: nenhuma alteração fica fora do histórico
+func (r *RoleServiceImpl) recordRoleHistory(ctx context.Context, role *model.Role, eventType model.RoleHistoryEventType, payload model.RoleHistoryPayload, actorID *uuid.UUID) error {
+	if r.historyService == nil {
+		return nil
+	}
+
+	ctx, err := requestctx.EnsureTenant(ctx, role.TenantID())
+	if err != nil {
+		return fmt.Errorf("erro ao registrar histórico da função: %w", err)
+	}
+
+	evt := &model.RoleHistoryEvent{
+		TenantID: role.TenantID(),
+		RoleID:   role.ID(),
+		Type:     eventType,
+		Payload:  payload,
+		ActorID:  actorID,
+	}
+	if err := r.historyService.Record(ctx, evt); err != nil {
+		return fmt.Errorf("erro ao registrar histórico da função: %w", err)
+	}
+	return nil
+}
+
 // publishRoleCreatedEvent publica evento de criação de função
 func (r *RoleServiceImpl) publishRoleCreatedEvent(role *model.Role) {
 	if r.eventPublisher == nil {
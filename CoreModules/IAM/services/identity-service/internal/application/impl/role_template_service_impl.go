@@ -0,0 +1,345 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Tamanho da página usado para ler as permissões das funções na deteção de desvios
+const roleTemplatePermissionPageSize = 100
+
+// RoleTemplateServiceImpl implementa a interface RoleTemplateService
+type RoleTemplateServiceImpl struct {
+	repository  repository.RoleTemplateRepository
+	roleService application.RoleService
+	now         func() time.Time
+}
+
+// NewRoleTemplateService cria uma nova instância de RoleTemplateService
+// As funções são criadas e recebem as permissões através do RoleService
+func NewRoleTemplateService(
+	repo repository.RoleTemplateRepository,
+	roleService application.RoleService,
+) application.RoleTemplateService {
+	return &RoleTemplateServiceImpl{
+		repository:  repo,
+		roleService: roleService,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// CreateTemplate publica um novo modelo no catálogo global
+func (s *RoleTemplateServiceImpl) CreateTemplate(ctx context.Context, req *application.CreateRoleTemplateRequest) (*model.RoleTemplate, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateServiceImpl.CreateTemplate", trace.WithAttributes(
+		attribute.String("template_code", req.Code),
+		attribute.String("market", req.Market),
+	))
+	defer span.End()
+
+	template, err := model.NewRoleTemplate(req.Code, req.Name, req.Description, req.Market, req.PermissionCodes, req.CreatedBy, s.now())
+	if err != nil {
+		return nil, err
+	}
+	template.Metadata = req.Metadata
+
+	if err := s.repository.CreateTemplate(ctx, template); err != nil {
+		if errors.Is(err, model.ErrRoleTemplateCodeAlreadyExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar modelo de função: %w", err)
+	}
+
+	log.Info().
+		Str("template_id", template.ID.String()).
+		Str("template_code", template.Code).
+		Str("market", template.Market).
+		Int("permissions", len(template.PermissionCodes)).
+		Msg("Modelo de função publicado")
+
+	return template, nil
+}
+
+// UpdateTemplate revê um modelo do catálogo
+func (s *RoleTemplateServiceImpl) UpdateTemplate(ctx context.Context, req *application.UpdateRoleTemplateRequest) (*model.RoleTemplate, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateServiceImpl.UpdateTemplate", trace.WithAttributes(
+		attribute.String("template_id", req.TemplateID.String()),
+	))
+	defer span.End()
+
+	template, err := s.GetTemplate(ctx, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedVersion := template.Version
+	bumped, err := template.Revise(req.Name, req.Description, req.PermissionCodes, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := s.repository.UpdateTemplate(ctx, template, expectedVersion); err != nil {
+		if errors.Is(err, model.ErrRoleTemplateConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao atualizar modelo de função: %w", err)
+	}
+
+	log.Info().
+		Str("template_id", template.ID.String()).
+		Str("template_code", template.Code).
+		Int("version", template.Version).
+		Bool("version_bumped", bumped).
+		Str("updated_by", req.UpdatedBy.String()).
+		Msg("Modelo de função revisto")
+
+	return template, nil
+}
+
+// GetTemplate recupera um modelo pelo ID
+func (s *RoleTemplateServiceImpl) GetTemplate(ctx context.Context, templateID uuid.UUID) (*model.RoleTemplate, error) {
+	template, err := s.repository.GetTemplate(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, model.ErrRoleTemplateNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter modelo de função: %w", err)
+	}
+	return template, nil
+}
+
+// ListTemplates recupera os modelos do catálogo que satisfazem o filtro
+func (s *RoleTemplateServiceImpl) ListTemplates(ctx context.Context, filter model.RoleTemplateFilter) ([]*model.RoleTemplate, error) {
+	templates, err := s.repository.ListTemplates(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar modelos de função: %w", err)
+	}
+	return templates, nil
+}
+
+// Instantiate cria a função no tenant a partir do modelo
+// A instância é gravada antes da atribuição das permissões para que uma falha a meio
+// fique visível na deteção de desvios
+func (s *RoleTemplateServiceImpl) Instantiate(ctx context.Context, req *application.InstantiateRoleTemplateRequest) (*application.RoleTemplateInstantiation, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateServiceImpl.Instantiate", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("template_id", req.TemplateID.String()),
+		attribute.String("market", req.Market),
+	))
+	defer span.End()
+
+	if req.TenantID == uuid.Nil {
+		return nil, fmt.Errorf("%w: tenant obrigatório", model.ErrInvalidRoleTemplate)
+	}
+
+	template, err := s.GetTemplate(ctx, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, model.ErrRoleTemplateInactive
+	}
+	if req.Market != "" && !template.AvailableIn(req.Market) {
+		return nil, fmt.Errorf("%w: modelo do mercado %s", model.ErrRoleTemplateNotAvailable, template.Market)
+	}
+
+	// Traduzir os códigos do modelo para os códigos de permissão do tenant
+	mapping := make(map[string]string)
+	tenantCodes := make([]string, 0, len(template.PermissionCodes))
+	for _, code := range template.PermissionCodes {
+		tenantCode := code
+		if mapped, ok := req.PermissionMapping[code]; ok && mapped != "" && mapped != code {
+			tenantCode = mapped
+			mapping[code] = mapped
+		}
+		tenantCodes = append(tenantCodes, tenantCode)
+	}
+
+	permissionIDs, err := s.repository.ResolvePermissions(ctx, req.TenantID, tenantCodes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver permissões do modelo: %w", err)
+	}
+
+	var unmapped []string
+	for i, code := range template.PermissionCodes {
+		if _, ok := permissionIDs[tenantCodes[i]]; !ok {
+			unmapped = append(unmapped, code)
+		}
+	}
+	if len(unmapped) > 0 && !req.AllowPartial {
+		return nil, fmt.Errorf("%w: %v", model.ErrRoleTemplatePermissionsUnmapped, unmapped)
+	}
+
+	roleCode := req.RoleCode
+	if roleCode == "" {
+		roleCode = template.Code
+	}
+	roleName := req.RoleName
+	if roleName == "" {
+		roleName = template.Name
+	}
+
+	role, err := s.roleService.CreateRole(ctx, application.CreateRoleRequest{
+		TenantID:    req.TenantID,
+		Code:        roleCode,
+		Name:        roleName,
+		Description: template.Description,
+		Type:        string(model.RoleTypeCustom),
+		CreatedBy:   req.InstantiatedBy,
+		Metadata: map[string]interface{}{
+			model.RoleMetadataTemplateID:      template.ID.String(),
+			model.RoleMetadataTemplateCode:    template.Code,
+			model.RoleMetadataTemplateVersion: template.Version,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &model.RoleTemplateInstance{
+		ID:                uuid.New(),
+		TemplateID:        template.ID,
+		TemplateVersion:   template.Version,
+		TenantID:          req.TenantID,
+		RoleID:            role.ID,
+		PermissionMapping: mapping,
+		UnmappedCodes:     unmapped,
+		InstantiatedBy:    req.InstantiatedBy,
+		InstantiatedAt:    s.now(),
+	}
+	if err := s.repository.CreateInstance(ctx, instance); err != nil {
+		if errors.Is(err, model.ErrRoleTemplateAlreadyInstantiated) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar instância do modelo de função: %w", err)
+	}
+
+	for _, tenantCode := range tenantCodes {
+		permissionID, ok := permissionIDs[tenantCode]
+		if !ok {
+			continue
+		}
+		if err := s.roleService.AssignPermission(ctx, req.TenantID, role.ID, permissionID, req.InstantiatedBy); err != nil {
+			return nil, fmt.Errorf("erro ao atribuir a permissão %s à função instanciada: %w", tenantCode, err)
+		}
+	}
+
+	log.Info().
+		Str("tenant_id", req.TenantID.String()).
+		Str("template_id", template.ID.String()).
+		Int("template_version", template.Version).
+		Str("role_id", role.ID.String()).
+		Int("permissions", len(permissionIDs)).
+		Strs("unmapped_codes", unmapped).
+		Msg("Modelo de função instanciado no tenant")
+
+	return &application.RoleTemplateInstantiation{Role: role, Instance: instance}, nil
+}
+
+// ListInstances recupera as funções do tenant instanciadas a partir de modelos
+func (s *RoleTemplateServiceImpl) ListInstances(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateInstance, error) {
+	instances, err := s.repository.ListInstances(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar instâncias de modelos de função: %w", err)
+	}
+	return instances, nil
+}
+
+// DetectDrift compara uma função instanciada com a versão atual do seu modelo
+func (s *RoleTemplateServiceImpl) DetectDrift(ctx context.Context, tenantID, roleID uuid.UUID) (*model.RoleTemplateDrift, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateServiceImpl.DetectDrift", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("role_id", roleID.String()),
+	))
+	defer span.End()
+
+	instance, err := s.repository.GetInstanceByRole(ctx, tenantID, roleID)
+	if err != nil {
+		if errors.Is(err, model.ErrRoleTemplateInstanceNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter instância do modelo de função: %w", err)
+	}
+
+	return s.detectDrift(ctx, instance, make(map[uuid.UUID]*model.RoleTemplate))
+}
+
+// DetectTenantDrift compara todas as funções instanciadas do tenant com os seus modelos
+func (s *RoleTemplateServiceImpl) DetectTenantDrift(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateDrift, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateServiceImpl.DetectTenantDrift", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	instances, err := s.ListInstances(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Os modelos são partilhados por várias instâncias; cada um é lido uma única vez
+	templates := make(map[uuid.UUID]*model.RoleTemplate)
+	drifts := make([]*model.RoleTemplateDrift, 0, len(instances))
+	for _, instance := range instances {
+		drift, err := s.detectDrift(ctx, instance, templates)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+
+	span.SetAttributes(attribute.Int("instances", len(instances)))
+	return drifts, nil
+}
+
+// detectDrift calcula o desvio de uma instância, reutilizando os modelos já lidos
+func (s *RoleTemplateServiceImpl) detectDrift(ctx context.Context, instance *model.RoleTemplateInstance, templates map[uuid.UUID]*model.RoleTemplate) (*model.RoleTemplateDrift, error) {
+	template, ok := templates[instance.TemplateID]
+	if !ok {
+		var err error
+		template, err = s.GetTemplate(ctx, instance.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		templates[instance.TemplateID] = template
+	}
+
+	roleCodes, err := s.rolePermissionCodes(ctx, instance.TenantID, instance.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ComputeRoleTemplateDrift(template, instance, roleCodes, s.now()), nil
+}
+
+// rolePermissionCodes lê todas as páginas de permissões da função
+func (s *RoleTemplateServiceImpl) rolePermissionCodes(ctx context.Context, tenantID, roleID uuid.UUID) ([]string, error) {
+	var codes []string
+	for page := 1; ; page++ {
+		permissions, total, err := s.roleService.GetRolePermissions(ctx, tenantID, roleID, application.Pagination{
+			Page:     page,
+			PageSize: roleTemplatePermissionPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter permissões da função: %w", err)
+		}
+		for _, permission := range permissions {
+			codes = append(codes, permission.Code)
+		}
+		if len(permissions) == 0 || int64(len(codes)) >= total {
+			return codes, nil
+		}
+	}
+}
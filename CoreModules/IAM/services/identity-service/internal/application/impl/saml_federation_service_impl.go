@@ -0,0 +1,598 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Validade padrão de um pedido de autenticação SAML à espera da resposta do IdP
+const DefaultSAMLAuthnRequestTTL = 10 * time.Minute
+
+// SAMLFederationServiceImpl implementa a interface SAMLFederationService
+type SAMLFederationServiceImpl struct {
+	repository      repository.SAMLFederationRepository
+	serviceProvider application.SAMLServiceProvider
+	roleService     application.RoleService
+	linkDetector    application.AccountLinkCandidateDetector
+	legalGate       application.LegalAcceptanceGate
+	requestTTL      time.Duration
+	now             func() time.Time
+}
+
+// NewSAMLFederationService cria uma nova instância de SAMLFederationService
+// As funções mapeadas são atribuídas e retiradas através do RoleService.
+// Com linkDetector, os NameIDs desconhecidos cujo email corresponde a uma conta local verificada
+// dão origem a um candidato à ligação em vez de um provisionamento.
+// Com legalGate, o login só é concluído depois de aceites os documentos legais obrigatórios.
+// Uma validade não positiva usa DefaultSAMLAuthnRequestTTL
+func NewSAMLFederationService(
+	repo repository.SAMLFederationRepository,
+	serviceProvider application.SAMLServiceProvider,
+	roleService application.RoleService,
+	linkDetector application.AccountLinkCandidateDetector,
+	legalGate application.LegalAcceptanceGate,
+	requestTTL time.Duration,
+) application.SAMLFederationService {
+	if requestTTL <= 0 {
+		requestTTL = DefaultSAMLAuthnRequestTTL
+	}
+	return &SAMLFederationServiceImpl{
+		repository:      repo,
+		serviceProvider: serviceProvider,
+		roleService:     roleService,
+		linkDetector:    linkDetector,
+		legalGate:       legalGate,
+		requestTTL:      requestTTL,
+		now:             func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// RegisterProvider regista um fornecedor de identidade no tenant
+func (s *SAMLFederationServiceImpl) RegisterProvider(ctx context.Context, req *application.RegisterSAMLProviderRequest) (*model.SAMLIdentityProvider, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationServiceImpl.RegisterProvider", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+	))
+	defer span.End()
+
+	now := s.now()
+	provider := &model.SAMLIdentityProvider{
+		ID:                  uuid.New(),
+		TenantID:            req.TenantID,
+		Name:                strings.TrimSpace(req.Name),
+		EntityID:            strings.TrimSpace(req.EntityID),
+		SSOURL:              strings.TrimSpace(req.SSOURL),
+		SigningCertificates: req.SigningCertificates,
+		NameIDFormat:        req.NameIDFormat,
+		AttributeMapping:    req.AttributeMapping,
+		RoleMappingRules:    req.RoleMappingRules,
+		JITProvisioning:     req.JITProvisioning,
+		IsActive:            true,
+		CreatedBy:           req.CreatedBy,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if req.MetadataXML != "" {
+		if err := s.applyMetadata(provider, req.MetadataXML); err != nil {
+			return nil, err
+		}
+	}
+	if err := provider.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkMappedRoles(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateProvider(ctx, provider); err != nil {
+		if errors.Is(err, model.ErrSAMLProviderAlreadyExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar fornecedor de identidade SAML: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", provider.TenantID.String()).
+		Str("provider_id", provider.ID.String()).
+		Str("entity_id", provider.EntityID).
+		Int("role_rules", len(provider.RoleMappingRules)).
+		Bool("jit_provisioning", provider.JITProvisioning).
+		Msg("Fornecedor de identidade SAML registado")
+
+	return provider, nil
+}
+
+// UpdateProvider altera um fornecedor de identidade do tenant
+func (s *SAMLFederationServiceImpl) UpdateProvider(ctx context.Context, req *application.UpdateSAMLProviderRequest) (*model.SAMLIdentityProvider, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationServiceImpl.UpdateProvider", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("provider_id", req.ProviderID.String()),
+	))
+	defer span.End()
+
+	provider, err := s.GetProvider(ctx, req.TenantID, req.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		provider.Name = name
+	}
+	if req.MetadataXML != "" {
+		if err := s.applyMetadata(provider, req.MetadataXML); err != nil {
+			return nil, err
+		}
+	}
+	if req.AttributeMapping != nil {
+		provider.AttributeMapping = *req.AttributeMapping
+	}
+	if req.RoleMappingRules != nil {
+		provider.RoleMappingRules = req.RoleMappingRules
+	}
+	if req.JITProvisioning != nil {
+		provider.JITProvisioning = *req.JITProvisioning
+	}
+	if req.IsActive != nil {
+		provider.IsActive = *req.IsActive
+	}
+	provider.UpdatedAt = s.now()
+
+	if err := provider.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkMappedRoles(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateProvider(ctx, provider); err != nil {
+		if errors.Is(err, model.ErrSAMLProviderNotFound) || errors.Is(err, model.ErrSAMLProviderAlreadyExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao atualizar fornecedor de identidade SAML: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", provider.TenantID.String()).
+		Str("provider_id", provider.ID.String()).
+		Bool("is_active", provider.IsActive).
+		Str("updated_by", req.UpdatedBy.String()).
+		Msg("Fornecedor de identidade SAML atualizado")
+
+	return provider, nil
+}
+
+// GetProvider recupera um fornecedor de identidade do tenant
+// Os fornecedores de outros tenants são tratados como inexistentes
+func (s *SAMLFederationServiceImpl) GetProvider(ctx context.Context, tenantID, providerID uuid.UUID) (*model.SAMLIdentityProvider, error) {
+	provider, err := s.getProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if provider.TenantID != tenantID {
+		return nil, model.ErrSAMLProviderNotFound
+	}
+	return provider, nil
+}
+
+// ListProviders recupera os fornecedores de identidade do tenant
+func (s *SAMLFederationServiceImpl) ListProviders(ctx context.Context, tenantID uuid.UUID) ([]*model.SAMLIdentityProvider, error) {
+	providers, err := s.repository.ListProviders(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar fornecedores de identidade SAML: %w", err)
+	}
+	return providers, nil
+}
+
+// ServiceProviderMetadata gera os metadados do SP para o fornecedor de identidade
+func (s *SAMLFederationServiceImpl) ServiceProviderMetadata(ctx context.Context, providerID uuid.UUID) ([]byte, error) {
+	provider, err := s.getProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.serviceProvider.Metadata(provider)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar metadados do SP: %w", err)
+	}
+	return metadata, nil
+}
+
+// StartLogin emite um pedido de autenticação para o IdP e regista-o para validar a resposta
+// O relay state é aleatório e identifica o pedido no regresso; o destino final fica no servidor
+func (s *SAMLFederationServiceImpl) StartLogin(ctx context.Context, providerID uuid.UUID, returnTo string) (*application.SAMLLoginRedirect, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationServiceImpl.StartLogin", trace.WithAttributes(
+		attribute.String("provider_id", providerID.String()),
+	))
+	defer span.End()
+
+	if !isRelativeReturnTo(returnTo) {
+		return nil, model.ErrInvalidSAMLReturnTo
+	}
+
+	provider, err := s.activeProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if ctx, err = requestctx.EnsureTenant(ctx, provider.TenantID); err != nil {
+		return nil, err
+	}
+
+	relayState, err := newRelayState()
+	if err != nil {
+		return nil, err
+	}
+
+	requestID, redirectURL, err := s.serviceProvider.AuthnRequest(provider, relayState)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar pedido de autenticação SAML: %w", err)
+	}
+
+	now := s.now()
+	request := &model.SAMLAuthnRequest{
+		ID:         requestID,
+		TenantID:   provider.TenantID,
+		ProviderID: provider.ID,
+		RelayState: relayState,
+		ReturnTo:   returnTo,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.requestTTL),
+	}
+	if err := s.repository.SaveAuthnRequest(ctx, request); err != nil {
+		return nil, fmt.Errorf("erro ao gravar pedido de autenticação SAML: %w", err)
+	}
+
+	return &application.SAMLLoginRedirect{
+		RedirectURL: redirectURL,
+		RequestID:   requestID,
+		RelayState:  relayState,
+	}, nil
+}
+
+// CompleteLogin valida a resposta do IdP e conclui o login federado
+// O pedido é consumido antes da validação, para que uma resposta intercetada não possa ser
+// reapresentada mesmo que a primeira tentativa falhe. Apenas os logins iniciados pelo SP são aceites.
+func (s *SAMLFederationServiceImpl) CompleteLogin(ctx context.Context, providerID uuid.UUID, samlResponse, relayState string) (*application.SAMLLoginResult, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationServiceImpl.CompleteLogin", trace.WithAttributes(
+		attribute.String("provider_id", providerID.String()),
+	))
+	defer span.End()
+
+	provider, err := s.activeProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if ctx, err = requestctx.EnsureTenant(ctx, provider.TenantID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	request, err := s.repository.ConsumeAuthnRequest(ctx, provider.ID, relayState, now)
+	if err != nil {
+		if errors.Is(err, model.ErrSAMLRequestNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao consumir pedido de autenticação SAML: %w", err)
+	}
+
+	assertion, err := s.serviceProvider.ParseResponse(provider, samlResponse, request.ID)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("tenant_id", provider.TenantID.String()).
+			Str("provider_id", provider.ID.String()).
+			Msg("Resposta SAML rejeitada")
+		return nil, err
+	}
+	if strings.TrimSpace(assertion.NameID) == "" {
+		return nil, fmt.Errorf("%w: asserção sem NameID", model.ErrInvalidSAMLResponse)
+	}
+
+	result := &application.SAMLLoginResult{
+		ReturnTo:     request.ReturnTo,
+		SessionIndex: assertion.SessionIndex,
+		RolesGranted: []uuid.UUID{},
+		RolesRevoked: []uuid.UUID{},
+	}
+
+	identity, err := s.repository.GetFederatedIdentity(ctx, provider.ID, assertion.NameID)
+	switch {
+	case errors.Is(err, model.ErrFederatedIdentityNotFound):
+		if result.LinkCandidate, err = s.detectLinkCandidate(ctx, provider, assertion); err != nil {
+			return nil, err
+		}
+		if result.LinkCandidate != nil {
+			// O login só é concluído depois de o usuário ligar as contas com a sua autenticação local
+			return result, nil
+		}
+		if !provider.JITProvisioning {
+			return nil, model.ErrSAMLProvisioningDisabled
+		}
+		result.User, identity, err = s.provision(ctx, provider, assertion, now)
+		if err != nil {
+			return nil, err
+		}
+		result.Provisioned = true
+	case err != nil:
+		return nil, fmt.Errorf("erro ao obter identidade federada: %w", err)
+	default:
+		result.User, err = s.repository.GetUser(ctx, provider.TenantID, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter usuário federado: %w", err)
+		}
+		if !result.User.CanAuthenticate() {
+			return nil, model.ErrFederatedUserNotAllowed
+		}
+	}
+
+	if s.legalGate != nil {
+		result.PendingDocuments, err = s.legalGate.LoginPendingDocuments(ctx, provider.TenantID, result.User.ID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar documentos legais por aceitar: %w", err)
+		}
+		if len(result.PendingDocuments) > 0 {
+			// O login só é concluído depois de o usuário aceitar os documentos obrigatórios
+			log.Info().
+				Str("tenant_id", provider.TenantID.String()).
+				Str("user_id", result.User.ID.String()).
+				Int("pending_documents", len(result.PendingDocuments)).
+				Msg("Login federado SAML à espera da aceitação de documentos legais")
+			return result, nil
+		}
+	}
+
+	result.RolesGranted, result.RolesRevoked = s.syncRoles(ctx, provider, identity, assertion)
+
+	identity.LastLoginAt = &now
+	if err := s.repository.RecordLogin(ctx, identity); err != nil {
+		return nil, fmt.Errorf("erro ao registar login federado: %w", err)
+	}
+	result.Identity = identity
+
+	log.Info().
+		Str("tenant_id", provider.TenantID.String()).
+		Str("provider_id", provider.ID.String()).
+		Str("user_id", identity.UserID.String()).
+		Bool("provisioned", result.Provisioned).
+		Int("roles_granted", len(result.RolesGranted)).
+		Int("roles_revoked", len(result.RolesRevoked)).
+		Msg("Login federado SAML concluído")
+
+	return result, nil
+}
+
+// provision cria o usuário e a identidade federada a partir da asserção
+// O email tem de estar livre no tenant: a ligação a contas locais existentes nunca é automática
+func (s *SAMLFederationServiceImpl) provision(ctx context.Context, provider *model.SAMLIdentityProvider, assertion *model.SAMLAssertion, now time.Time) (*model.User, *model.FederatedIdentity, error) {
+	mapping := provider.AttributeMapping.WithDefaults()
+
+	email := assertionEmail(provider, assertion)
+	if email == "" {
+		return nil, nil, fmt.Errorf("%w: asserção sem email para provisionar o usuário", model.ErrInvalidSAMLResponse)
+	}
+
+	user, err := model.NewUser(provider.TenantID, email, email, assertion.Attribute(mapping.FirstName), assertion.Attribute(mapping.LastName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", model.ErrInvalidSAMLResponse, err)
+	}
+	if displayName := assertion.Attribute(mapping.DisplayName); displayName != "" {
+		user.DisplayName = displayName
+	}
+	// O IdP é da confiança do tenant: o usuário fica ativo e o email verificado
+	user.Status = model.UserStatusActive
+	user.StatusChangedAt = &now
+	user.EmailVerified = true
+	user.Metadata[model.UserMetadataSAMLProviderID] = provider.ID.String()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.Credentials = &model.UserCredential{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		Provider:       model.AuthProviderCustomSAML,
+		ProviderUserID: assertion.NameID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	identity := &model.FederatedIdentity{
+		ID:             uuid.New(),
+		TenantID:       provider.TenantID,
+		ProviderID:     provider.ID,
+		Subject:        assertion.NameID,
+		UserID:         user.ID,
+		GrantedRoleIDs: []uuid.UUID{},
+		CreatedAt:      now,
+	}
+
+	if err := s.repository.ProvisionUser(ctx, user, identity); err != nil {
+		if errors.Is(err, model.ErrSAMLAccountConflict) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("erro ao provisionar usuário federado: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", provider.TenantID.String()).
+		Str("provider_id", provider.ID.String()).
+		Str("user_id", user.ID.String()).
+		Msg("Usuário provisionado no primeiro login federado")
+
+	return user, identity, nil
+}
+
+// detectLinkCandidate procura uma conta local com o email verificado da asserção
+// Sem detetor configurado ou sem email na asserção, não há candidato
+func (s *SAMLFederationServiceImpl) detectLinkCandidate(ctx context.Context, provider *model.SAMLIdentityProvider, assertion *model.SAMLAssertion) (*model.AccountLinkCandidate, error) {
+	email := assertionEmail(provider, assertion)
+	if s.linkDetector == nil || email == "" {
+		return nil, nil
+	}
+
+	candidate, err := s.linkDetector.DetectCandidate(ctx, &application.DetectAccountLinkRequest{
+		TenantID:   provider.TenantID,
+		ProviderID: provider.ID,
+		Subject:    assertion.NameID,
+		Email:      email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao detetar candidato à ligação de contas: %w", err)
+	}
+	return candidate, nil
+}
+
+// assertionEmail retorna o email da asserção em minúsculas, recorrendo ao NameID quando é um email
+func assertionEmail(provider *model.SAMLIdentityProvider, assertion *model.SAMLAssertion) string {
+	email := assertion.Attribute(provider.AttributeMapping.WithDefaults().Email)
+	if email == "" && strings.Contains(assertion.NameID, "@") {
+		email = assertion.NameID
+	}
+	return strings.ToLower(email)
+}
+
+// syncRoles alinha as funções atribuídas pela federação com as regras satisfeitas pela asserção
+// Só são retiradas funções que a própria federação atribuiu; funções que o usuário já tinha
+// por outra via não passam a ser geridas pela federação. As falhas individuais são registadas
+// e a função volta a ser avaliada no login seguinte.
+func (s *SAMLFederationServiceImpl) syncRoles(ctx context.Context, provider *model.SAMLIdentityProvider, identity *model.FederatedIdentity, assertion *model.SAMLAssertion) (granted, revoked []uuid.UUID) {
+	granted, revoked = []uuid.UUID{}, []uuid.UUID{}
+	now := s.now()
+
+	mapped := provider.MapRoles(assertion.Attributes)
+	wanted := make(map[uuid.UUID]bool, len(mapped))
+	for _, roleID := range mapped {
+		wanted[roleID] = true
+	}
+	previous := make(map[uuid.UUID]bool, len(identity.GrantedRoleIDs))
+	for _, roleID := range identity.GrantedRoleIDs {
+		previous[roleID] = true
+	}
+
+	managed := []uuid.UUID{}
+	for _, roleID := range identity.GrantedRoleIDs {
+		if wanted[roleID] {
+			managed = append(managed, roleID)
+			continue
+		}
+		err := s.roleService.RevokeUserFromRole(ctx, provider.TenantID, roleID, identity.UserID, identity.UserID)
+		if err != nil && !errors.Is(err, application.ErrUserNotAssigned) && !errors.Is(err, application.ErrRoleNotFound) {
+			log.Error().Err(err).
+				Str("tenant_id", provider.TenantID.String()).
+				Str("user_id", identity.UserID.String()).
+				Str("role_id", roleID.String()).
+				Msg("Falha ao retirar função deixada de mapear pela federação")
+			managed = append(managed, roleID)
+			continue
+		}
+		revoked = append(revoked, roleID)
+	}
+
+	for _, roleID := range mapped {
+		if previous[roleID] {
+			continue
+		}
+		err := s.roleService.AssignUserToRole(ctx, provider.TenantID, roleID, identity.UserID, now, nil, identity.UserID)
+		if errors.Is(err, application.ErrUserAlreadyAssigned) {
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", provider.TenantID.String()).
+				Str("user_id", identity.UserID.String()).
+				Str("role_id", roleID.String()).
+				Msg("Falha ao atribuir função mapeada pela federação")
+			continue
+		}
+		granted = append(granted, roleID)
+		managed = append(managed, roleID)
+	}
+
+	identity.GrantedRoleIDs = managed
+	return granted, revoked
+}
+
+// applyMetadata substitui os dados do IdP pelos lidos dos seus metadados
+func (s *SAMLFederationServiceImpl) applyMetadata(provider *model.SAMLIdentityProvider, metadataXML string) error {
+	parsed, err := s.serviceProvider.ParseIdPMetadata([]byte(metadataXML))
+	if err != nil {
+		return err
+	}
+	provider.EntityID = parsed.EntityID
+	provider.SSOURL = parsed.SSOURL
+	provider.SigningCertificates = parsed.SigningCertificates
+	if parsed.NameIDFormat != "" {
+		provider.NameIDFormat = parsed.NameIDFormat
+	}
+	return nil
+}
+
+// checkMappedRoles verifica que as funções das regras existem no tenant do fornecedor
+func (s *SAMLFederationServiceImpl) checkMappedRoles(ctx context.Context, provider *model.SAMLIdentityProvider) error {
+	checked := make(map[uuid.UUID]bool)
+	for _, rule := range provider.RoleMappingRules {
+		if checked[rule.RoleID] {
+			continue
+		}
+		if _, err := s.roleService.GetRole(ctx, provider.TenantID, rule.RoleID); err != nil {
+			if errors.Is(err, application.ErrRoleNotFound) {
+				return fmt.Errorf("%w: função %s inexistente no tenant", model.ErrInvalidSAMLProvider, rule.RoleID)
+			}
+			return fmt.Errorf("erro ao verificar função mapeada: %w", err)
+		}
+		checked[rule.RoleID] = true
+	}
+	return nil
+}
+
+// getProvider recupera um fornecedor de identidade de qualquer tenant
+// Nos fluxos de login o tenant só é conhecido pelo fornecedor, pelo que a consulta é feita no âmbito do sistema
+func (s *SAMLFederationServiceImpl) getProvider(ctx context.Context, providerID uuid.UUID) (*model.SAMLIdentityProvider, error) {
+	provider, err := s.repository.GetProvider(requestctx.WithSystemScope(ctx, "saml.provider_lookup"), providerID)
+	if err != nil {
+		if errors.Is(err, model.ErrSAMLProviderNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter fornecedor de identidade SAML: %w", err)
+	}
+	return provider, nil
+}
+
+// activeProvider recupera um fornecedor de identidade ativo
+func (s *SAMLFederationServiceImpl) activeProvider(ctx context.Context, providerID uuid.UUID) (*model.SAMLIdentityProvider, error) {
+	provider, err := s.getProvider(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.IsActive {
+		return nil, model.ErrSAMLProviderInactive
+	}
+	return provider, nil
+}
+
+// isRelativeReturnTo aceita apenas caminhos relativos à aplicação, evitando redirecionamentos abertos
+func isRelativeReturnTo(returnTo string) bool {
+	if returnTo == "" {
+		return true
+	}
+	return strings.HasPrefix(returnTo, "/") && !strings.HasPrefix(returnTo, "//") && !strings.Contains(returnTo, "\\")
+}
+
+// newRelayState gera um identificador aleatório e imprevisível para o pedido
+func newRelayState() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar relay state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,782 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das contas de serviço
+const (
+	DefaultServiceAccountTokenTTL     = 15 * time.Minute
+	MaxServiceAccountTokenTTL         = time.Hour
+	DefaultServiceAccountDormantAfter = 90 * 24 * time.Hour
+	DefaultServiceAccountUsageDays    = 30
+	MaxServiceAccountUsageDays        = 365
+)
+
+// ServiceAccountAPIKeyPrefix antecede as chaves de API das contas de serviço, para que sejam
+// reconhecidas pelos scanners de segredos; a chave tem o formato iamsa_<prefixo>_<segredo>
+const ServiceAccountAPIKeyPrefix = "iamsa_"
+
+// serviceAccountClientIDPrefix antecede os client_id dos clientes OIDC das contas de serviço
+const serviceAccountClientIDPrefix = "sa-"
+
+// errServiceAccountRejected identifica, no log, as causas das autenticações recusadas
+var errServiceAccountRejected = errors.New("credenciais recusadas")
+
+// ServiceAccountConfig configura as contas de serviço
+type ServiceAccountConfig struct {
+	// Emissor (iss) dos tokens de acesso das contas de serviço
+	Issuer string
+	// Validade dos tokens de acesso emitidos na autenticação
+	TokenTTL time.Duration
+	// Tempo sem autenticações a partir do qual uma conta ativa é considerada inativa
+	DormantAfter time.Duration
+	// Clusters Kubernetes cujos tokens de contas de serviço são aceites, pelo nome
+	KubernetesClusters map[string]application.KubernetesTokenReviewer
+}
+
+// DefaultServiceAccountConfig retorna a configuração padrão das contas de serviço
+func DefaultServiceAccountConfig() ServiceAccountConfig {
+	return ServiceAccountConfig{
+		TokenTTL:     DefaultServiceAccountTokenTTL,
+		DormantAfter: DefaultServiceAccountDormantAfter,
+	}
+}
+
+// ServiceAccountServiceImpl implementa a interface ServiceAccountService
+type ServiceAccountServiceImpl struct {
+	repository repository.ServiceAccountRepository
+	codec      application.TokenExchangeCodec
+	config     ServiceAccountConfig
+	now        func() time.Time
+}
+
+// NewServiceAccountService cria uma nova instância de ServiceAccountService
+// O codec assina os tokens de acesso emitidos na autenticação. Uma validade não positiva ou
+// superior a MaxServiceAccountTokenTTL usa o padrão, tal como um período de inatividade não positivo
+func NewServiceAccountService(repo repository.ServiceAccountRepository, codec application.TokenExchangeCodec, config ServiceAccountConfig) application.ServiceAccountService {
+	defaults := DefaultServiceAccountConfig()
+	if config.TokenTTL <= 0 || config.TokenTTL > MaxServiceAccountTokenTTL {
+		config.TokenTTL = defaults.TokenTTL
+	}
+	if config.DormantAfter <= 0 {
+		config.DormantAfter = defaults.DormantAfter
+	}
+
+	return &ServiceAccountServiceImpl{
+		repository: repo,
+		codec:      codec,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// ListServiceAccounts recupera as contas de serviço do tenant
+func (s *ServiceAccountServiceImpl) ListServiceAccounts(ctx context.Context, tenantID uuid.UUID, filter model.ServiceAccountFilter) ([]*model.ServiceAccount, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: estado %q desconhecido", model.ErrInvalidServiceAccount, filter.Status)
+	}
+
+	accounts, err := s.repository.ListServiceAccounts(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar contas de serviço: %w", err)
+	}
+	if accounts == nil {
+		accounts = []*model.ServiceAccount{}
+	}
+	return accounts, nil
+}
+
+// GetServiceAccount recupera uma conta de serviço do tenant
+func (s *ServiceAccountServiceImpl) GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.ServiceAccount, error) {
+	account, err := s.repository.GetServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		if errors.Is(err, model.ErrServiceAccountNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter conta de serviço: %w", err)
+	}
+	return account, nil
+}
+
+// CreateServiceAccount cria uma conta de serviço ativa, sem funções nem credenciais
+func (s *ServiceAccountServiceImpl) CreateServiceAccount(ctx context.Context, req *application.SaveServiceAccountRequest) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.CreateServiceAccount", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("service_account.name", req.Name),
+	))
+	defer span.End()
+
+	now := s.now()
+	account := &model.ServiceAccount{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      model.ServiceAccountActive,
+		RoleIDs:     []uuid.UUID{},
+		CreatedBy:   req.ActorID,
+		UpdatedBy:   req.ActorID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	account.Normalize()
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateServiceAccount(ctx, account); err != nil {
+		if errors.Is(err, model.ErrServiceAccountConflict) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar conta de serviço: %w", err)
+	}
+
+	s.logAccountChange(account, req.ActorID, "criada")
+	return account, nil
+}
+
+// UpdateServiceAccount altera o nome, a descrição e o estado de uma conta de serviço
+func (s *ServiceAccountServiceImpl) UpdateServiceAccount(ctx context.Context, req *application.SaveServiceAccountRequest) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.UpdateServiceAccount", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("service_account.id", req.AccountID.String()),
+	))
+	defer span.End()
+
+	account, err := s.GetServiceAccount(ctx, req.TenantID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Name = req.Name
+	account.Description = req.Description
+	if req.Status != "" {
+		account.Status = req.Status
+	}
+	return s.save(ctx, account, req.ActorID, "alterada")
+}
+
+// BindRole atribui uma função do tenant à conta de serviço
+func (s *ServiceAccountServiceImpl) BindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.BindRole", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("role_id", roleID.String()),
+	))
+	defer span.End()
+
+	account, err := s.GetServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, bound := range account.RoleIDs {
+		if bound == roleID {
+			return account, nil
+		}
+	}
+	if len(account.RoleIDs) >= model.MaxServiceAccountRoles {
+		return nil, fmt.Errorf("%w: máximo %d", model.ErrServiceAccountRoleLimit, model.MaxServiceAccountRoles)
+	}
+
+	if err := s.repository.BindRole(ctx, tenantID, accountID, roleID, actorID, s.now()); err != nil {
+		if errors.Is(err, model.ErrServiceAccountNotFound) || errors.Is(err, model.ErrServiceAccountRoleNotFound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao atribuir função à conta de serviço: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("service_account_id", accountID.String()).
+		Str("role_id", roleID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Função atribuída à conta de serviço")
+	return s.GetServiceAccount(ctx, tenantID, accountID)
+}
+
+// UnbindRole retira uma função da conta de serviço
+func (s *ServiceAccountServiceImpl) UnbindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.UnbindRole", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("role_id", roleID.String()),
+	))
+	defer span.End()
+
+	if err := s.repository.UnbindRole(ctx, tenantID, accountID, roleID); err != nil {
+		if errors.Is(err, model.ErrServiceAccountRoleNotBound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao retirar função da conta de serviço: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("service_account_id", accountID.String()).
+		Str("role_id", roleID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Função retirada da conta de serviço")
+	return s.GetServiceAccount(ctx, tenantID, accountID)
+}
+
+// BindWorkloadIdentity liga a conta de serviço a uma conta de serviço Kubernetes de um cluster configurado
+func (s *ServiceAccountServiceImpl) BindWorkloadIdentity(ctx context.Context, tenantID, accountID uuid.UUID, binding model.WorkloadIdentityBinding, actorID uuid.UUID) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.BindWorkloadIdentity", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("kubernetes.cluster", binding.Cluster),
+	))
+	defer span.End()
+
+	binding.Normalize()
+	if err := binding.Validate(); err != nil {
+		return nil, err
+	}
+	if _, ok := s.config.KubernetesClusters[binding.Cluster]; !ok {
+		return nil, fmt.Errorf("%w: cluster %q não configurado", model.ErrInvalidWorkloadIdentity, binding.Cluster)
+	}
+
+	account, err := s.GetServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	account.WorkloadIdentity = &binding
+	return s.save(ctx, account, actorID, "ligada ao Kubernetes")
+}
+
+// UnbindWorkloadIdentity remove a ligação da conta de serviço ao Kubernetes
+func (s *ServiceAccountServiceImpl) UnbindWorkloadIdentity(ctx context.Context, tenantID, accountID, actorID uuid.UUID) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.UnbindWorkloadIdentity", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+	))
+	defer span.End()
+
+	account, err := s.GetServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.WorkloadIdentity == nil {
+		return account, nil
+	}
+	account.WorkloadIdentity = nil
+	return s.save(ctx, account, actorID, "desligada do Kubernetes")
+}
+
+// ListCredentials recupera as credenciais da conta de serviço, sem os segredos
+func (s *ServiceAccountServiceImpl) ListCredentials(ctx context.Context, tenantID, accountID uuid.UUID) ([]*model.ServiceAccountCredential, error) {
+	if _, err := s.GetServiceAccount(ctx, tenantID, accountID); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.repository.ListCredentials(ctx, tenantID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar credenciais da conta de serviço: %w", err)
+	}
+	if credentials == nil {
+		credentials = []*model.ServiceAccountCredential{}
+	}
+	return credentials, nil
+}
+
+// IssueCredential emite uma chave de API ou um cliente OIDC, ou regista um certificado mTLS
+//
+// As chaves de API e os segredos dos clientes são gerados aqui e só são devolvidos nesta resposta;
+// fica guardado apenas o seu hash. Um certificado mTLS é identificado pela impressão digital
+// SHA-256 e a credencial expira, no máximo, no fim da validade do certificado.
+func (s *ServiceAccountServiceImpl) IssueCredential(ctx context.Context, req *application.IssueServiceAccountCredentialRequest) (*application.IssuedServiceAccountCredential, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.IssueCredential", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("service_account.id", req.AccountID.String()),
+		attribute.String("credential.type", string(req.Type)),
+	))
+	defer span.End()
+
+	existing, err := s.ListCredentials(ctx, req.TenantID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	active := 0
+	for _, credential := range existing {
+		if credential.IsActive(now) {
+			active++
+		}
+	}
+	if active >= model.MaxServiceAccountCredentials {
+		return nil, fmt.Errorf("%w: máximo %d", model.ErrServiceAccountCredentialLimit, model.MaxServiceAccountCredentials)
+	}
+
+	credential := &model.ServiceAccountCredential{
+		ID:               uuid.New(),
+		TenantID:         req.TenantID,
+		ServiceAccountID: req.AccountID,
+		Type:             req.Type,
+		Name:             strings.TrimSpace(req.Name),
+		ExpiresAt:        req.ExpiresAt,
+		CreatedBy:        req.ActorID,
+		CreatedAt:        now,
+	}
+
+	var secret string
+	switch req.Type {
+	case model.CredentialTypeAPIKey:
+		prefix, err := randomHex(8)
+		if err != nil {
+			return nil, err
+		}
+		keySecret, err := randomSecret()
+		if err != nil {
+			return nil, err
+		}
+		credential.KeyPrefix = prefix
+		credential.SecretHash = hashServiceAccountSecret(keySecret)
+		secret = ServiceAccountAPIKeyPrefix + prefix + "_" + keySecret
+	case model.CredentialTypeOIDCClient:
+		clientID, err := randomHex(12)
+		if err != nil {
+			return nil, err
+		}
+		if secret, err = randomSecret(); err != nil {
+			return nil, err
+		}
+		credential.ClientID = serviceAccountClientIDPrefix + clientID
+		credential.SecretHash = hashServiceAccountSecret(secret)
+	case model.CredentialTypeMTLSCert:
+		certificate, err := parseServiceAccountCertificate(req.CertificatePEM, now)
+		if err != nil {
+			return nil, err
+		}
+		credential.CertificateFingerprint = model.CertificateFingerprint(certificate.Raw)
+		credential.CertificateSubject = certificate.Subject.String()
+		if credential.ExpiresAt == nil || certificate.NotAfter.Before(*credential.ExpiresAt) {
+			notAfter := certificate.NotAfter.UTC()
+			credential.ExpiresAt = &notAfter
+		}
+	}
+	if err := credential.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateCredential(ctx, credential); err != nil {
+		if errors.Is(err, model.ErrServiceAccountCredentialConflict) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar credencial da conta de serviço: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", req.TenantID.String()).
+		Str("service_account_id", req.AccountID.String()).
+		Str("credential_id", credential.ID.String()).
+		Str("credential_type", string(credential.Type)).
+		Str("actor_id", req.ActorID.String()).
+		Msg("Credencial da conta de serviço emitida")
+
+	return &application.IssuedServiceAccountCredential{Credential: credential, Secret: secret}, nil
+}
+
+// RevokeCredential revoga uma credencial da conta de serviço
+func (s *ServiceAccountServiceImpl) RevokeCredential(ctx context.Context, tenantID, accountID, credentialID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.RevokeCredential", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("credential.id", credentialID.String()),
+	))
+	defer span.End()
+
+	if err := s.repository.RevokeCredential(ctx, tenantID, accountID, credentialID, actorID, s.now()); err != nil {
+		if errors.Is(err, model.ErrServiceAccountCredentialNotFound) {
+			return err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao revogar credencial da conta de serviço: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("service_account_id", accountID.String()).
+		Str("credential_id", credentialID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Credencial da conta de serviço revogada")
+	return nil
+}
+
+// Authenticate autentica uma conta de serviço ativa e emite um token de acesso de curta duração
+//
+// As chaves de API e os clientes OIDC são encontrados pelo identificador público e o segredo é
+// comparado com o hash guardado; os certificados mTLS pela impressão digital do certificado da
+// ligação. Os tokens Kubernetes são validados por TokenReview no cluster indicado e autenticam a
+// conta ligada à conta de serviço Kubernetes devolvida. A causa de uma recusa só fica no log.
+func (s *ServiceAccountServiceImpl) Authenticate(ctx context.Context, req *application.ServiceAccountAuthRequest) (*application.ServiceAccountTokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.Authenticate")
+	defer span.End()
+
+	method, err := serviceAccountAuthMethod(req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("service_account.auth_method", string(method)))
+
+	now := s.now()
+	var (
+		account    *model.ServiceAccount
+		credential *model.ServiceAccountCredential
+	)
+	switch method {
+	case model.AuthMethodKubernetes:
+		account, err = s.authenticateWorkload(ctx, req)
+	default:
+		credential, err = s.authenticateCredential(ctx, method, req, now)
+		if err == nil {
+			account, err = s.repository.GetServiceAccount(ctx, credential.TenantID, credential.ServiceAccountID)
+		}
+	}
+	if err != nil {
+		return nil, s.reject(ctx, method, req, err)
+	}
+	if !account.IsActive() {
+		return nil, s.reject(ctx, method, req, fmt.Errorf("%w: conta de serviço %s desativada", errServiceAccountRejected, account.ID))
+	}
+	span.SetAttributes(
+		attribute.String("tenant_id", account.TenantID.String()),
+		attribute.String("service_account.id", account.ID.String()),
+	)
+
+	claims := &model.TokenClaims{
+		TokenID:   uuid.NewString(),
+		Issuer:    s.config.Issuer,
+		Subject:   account.ID.String(),
+		TenantID:  account.TenantID.String(),
+		ClientID:  account.Name,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.config.TokenTTL),
+	}
+	token, err := s.codec.Sign(claims)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao assinar token da conta de serviço: %w", err)
+	}
+
+	// Uma falha na contabilização não impede a autenticação, que já foi validada
+	usage := &model.ServiceAccountUsage{
+		TenantID:         account.TenantID,
+		ServiceAccountID: account.ID,
+		Method:           method,
+		UsedAt:           now,
+	}
+	if credential != nil {
+		usage.CredentialID = &credential.ID
+	}
+	if err := s.repository.RecordUsage(ctx, usage); err != nil {
+		span.RecordError(err)
+		log.Error().Err(err).
+			Str("tenant_id", account.TenantID.String()).
+			Str("service_account_id", account.ID.String()).
+			Msg("Erro ao contabilizar utilização da conta de serviço")
+	}
+
+	log.Info().
+		Str("tenant_id", account.TenantID.String()).
+		Str("service_account_id", account.ID.String()).
+		Str("service_account", account.Name).
+		Str("method", string(method)).
+		Str("ip_address", req.IPAddress).
+		Str("token_id", claims.TokenID).
+		Msg("Conta de serviço autenticada")
+
+	return &application.ServiceAccountTokenResponse{
+		AccessToken:      token,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.config.TokenTTL / time.Second),
+		ServiceAccountID: account.ID,
+	}, nil
+}
+
+// UsageReport resume a utilização das contas de serviço do tenant e identifica as contas inativas
+//
+// O período vai do início do dia Days-1 dias atrás até agora. Uma conta ativa é inativa quando
+// não se autentica há DormantAfter ou, se nunca foi usada, quando foi criada há mais do que isso.
+// As contas são ordenadas das mais tempo sem uso para as mais recentes.
+func (s *ServiceAccountServiceImpl) UsageReport(ctx context.Context, tenantID uuid.UUID, filter application.ServiceAccountUsageFilter) (*model.ServiceAccountUsageReport, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountServiceImpl.UsageReport", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	if filter.Days == 0 {
+		filter.Days = DefaultServiceAccountUsageDays
+	}
+	if filter.Days < 0 || filter.Days > MaxServiceAccountUsageDays {
+		return nil, fmt.Errorf("%w: o período deve estar entre 1 e %d dias", model.ErrInvalidServiceAccountUsageFilter, MaxServiceAccountUsageDays)
+	}
+
+	now := s.now()
+	since := now.Truncate(24*time.Hour).AddDate(0, 0, -(filter.Days - 1))
+	accounts, err := s.repository.ListServiceAccounts(ctx, tenantID, model.ServiceAccountFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar contas de serviço: %w", err)
+	}
+	usage, err := s.repository.ListDailyUsage(ctx, tenantID, since)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar utilização das contas de serviço: %w", err)
+	}
+
+	summaries := make(map[uuid.UUID]*model.ServiceAccountUsageSummary, len(accounts))
+	days := make(map[uuid.UUID]map[time.Time]bool, len(accounts))
+	report := &model.ServiceAccountUsageReport{
+		Since:            since,
+		GeneratedAt:      now,
+		DormantAfterDays: int(s.config.DormantAfter / (24 * time.Hour)),
+		Accounts:         []*model.ServiceAccountUsageSummary{},
+	}
+	for _, account := range accounts {
+		reference := account.CreatedAt
+		if account.LastUsedAt != nil {
+			reference = *account.LastUsedAt
+		}
+		summary := &model.ServiceAccountUsageSummary{
+			ServiceAccountID: account.ID,
+			Name:             account.Name,
+			Status:           account.Status,
+			ByMethod:         make(map[model.ServiceAccountAuthMethod]int),
+			LastUsedAt:       account.LastUsedAt,
+			IdleDays:         int(now.Sub(reference) / (24 * time.Hour)),
+			Dormant:          account.IsDormant(now, s.config.DormantAfter),
+		}
+		summaries[account.ID] = summary
+		days[account.ID] = make(map[time.Time]bool)
+		if summary.Dormant {
+			report.DormantAccounts++
+		}
+		if summary.Dormant || !filter.DormantOnly {
+			report.Accounts = append(report.Accounts, summary)
+		}
+	}
+	for _, day := range usage {
+		summary, ok := summaries[day.ServiceAccountID]
+		if !ok {
+			continue
+		}
+		summary.Authentications += day.Authentications
+		summary.ByMethod[day.Method] += day.Authentications
+		days[day.ServiceAccountID][day.Day] = true
+	}
+	for id, summary := range summaries {
+		summary.ActiveDays = len(days[id])
+	}
+
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		if report.Accounts[i].IdleDays != report.Accounts[j].IdleDays {
+			return report.Accounts[i].IdleDays > report.Accounts[j].IdleDays
+		}
+		return report.Accounts[i].Name < report.Accounts[j].Name
+	})
+	span.SetAttributes(attribute.Int("service_account.dormant", report.DormantAccounts))
+	return report, nil
+}
+
+// authenticateCredential encontra a credencial apresentada e verifica o segredo e a validade
+func (s *ServiceAccountServiceImpl) authenticateCredential(ctx context.Context, method model.ServiceAccountAuthMethod, req *application.ServiceAccountAuthRequest, now time.Time) (*model.ServiceAccountCredential, error) {
+	var identifier, secret string
+	switch method {
+	case model.AuthMethodAPIKey:
+		prefix, keySecret, ok := strings.Cut(strings.TrimPrefix(req.APIKey, ServiceAccountAPIKeyPrefix), "_")
+		if !ok || !strings.HasPrefix(req.APIKey, ServiceAccountAPIKeyPrefix) {
+			return nil, fmt.Errorf("%w: formato da chave de API inválido", errServiceAccountRejected)
+		}
+		identifier, secret = prefix, keySecret
+	case model.AuthMethodOIDCClient:
+		identifier, secret = req.ClientID, req.ClientSecret
+	case model.AuthMethodMTLSCert:
+		identifier = strings.ToLower(req.CertificateFingerprint)
+	}
+
+	credential, err := s.repository.FindCredential(ctx, model.ServiceAccountCredentialType(method), identifier)
+	if err != nil {
+		return nil, err
+	}
+	if credential.SecretHash != nil && subtle.ConstantTimeCompare(hashServiceAccountSecret(secret), credential.SecretHash) != 1 {
+		return nil, fmt.Errorf("%w: segredo inválido para a credencial %s", errServiceAccountRejected, credential.ID)
+	}
+	if !credential.IsActive(now) {
+		return nil, fmt.Errorf("%w: credencial %s revogada ou expirada", errServiceAccountRejected, credential.ID)
+	}
+	return credential, nil
+}
+
+// authenticateWorkload valida o token Kubernetes no cluster e encontra a conta de serviço ligada
+func (s *ServiceAccountServiceImpl) authenticateWorkload(ctx context.Context, req *application.ServiceAccountAuthRequest) (*model.ServiceAccount, error) {
+	reviewer, ok := s.config.KubernetesClusters[req.KubernetesCluster]
+	if !ok {
+		return nil, fmt.Errorf("%w: cluster %q não configurado", errServiceAccountRejected, req.KubernetesCluster)
+	}
+	username, err := reviewer.Review(ctx, req.KubernetesToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: token recusado pelo cluster %s: %v", errServiceAccountRejected, req.KubernetesCluster, err)
+	}
+	binding, err := model.ParseKubernetesServiceAccountUser(req.KubernetesCluster, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.FindByWorkloadIdentity(ctx, *binding)
+}
+
+// reject regista a autenticação recusada e retorna o erro genérico; os erros que não resultam
+// das credenciais apresentadas (ex.: falhas da base de dados) são retornados como erros internos
+func (s *ServiceAccountServiceImpl) reject(ctx context.Context, method model.ServiceAccountAuthMethod, req *application.ServiceAccountAuthRequest, reason error) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(codes.Error, reason.Error())
+
+	if !isServiceAccountRejection(reason) {
+		return fmt.Errorf("erro ao autenticar conta de serviço: %w", reason)
+	}
+
+	log.Warn().
+		Str("method", string(method)).
+		Str("ip_address", req.IPAddress).
+		Err(reason).
+		Msg("Autenticação de conta de serviço recusada")
+	return model.ErrServiceAccountAuthenticationFailed
+}
+
+// save valida e grava uma conta de serviço alterada
+func (s *ServiceAccountServiceImpl) save(ctx context.Context, account *model.ServiceAccount, actorID uuid.UUID, change string) (*model.ServiceAccount, error) {
+	account.UpdatedBy = actorID
+	account.UpdatedAt = s.now()
+	account.Normalize()
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateServiceAccount(ctx, account); err != nil {
+		if errors.Is(err, model.ErrServiceAccountNotFound) || errors.Is(err, model.ErrServiceAccountConflict) ||
+			errors.Is(err, model.ErrWorkloadIdentityConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar conta de serviço: %w", err)
+	}
+
+	s.logAccountChange(account, actorID, change)
+	return account, nil
+}
+
+// logAccountChange regista a criação ou alteração de uma conta de serviço
+func (s *ServiceAccountServiceImpl) logAccountChange(account *model.ServiceAccount, actorID uuid.UUID, change string) {
+	event := log.Info().
+		Str("tenant_id", account.TenantID.String()).
+		Str("service_account_id", account.ID.String()).
+		Str("name", account.Name).
+		Str("status", string(account.Status)).
+		Str("actor_id", actorID.String())
+	if account.WorkloadIdentity != nil {
+		event = event.
+			Str("kubernetes_cluster", account.WorkloadIdentity.Cluster).
+			Str("kubernetes_service_account", account.WorkloadIdentity.Username())
+	}
+	event.Msgf("Conta de serviço %s", change)
+}
+
+// serviceAccountAuthMethod identifica o método do pedido de autenticação, que tem de ser único
+func serviceAccountAuthMethod(req *application.ServiceAccountAuthRequest) (model.ServiceAccountAuthMethod, error) {
+	var methods []model.ServiceAccountAuthMethod
+	if req.APIKey != "" {
+		methods = append(methods, model.AuthMethodAPIKey)
+	}
+	if req.ClientID != "" || req.ClientSecret != "" {
+		if req.ClientID == "" || req.ClientSecret == "" {
+			return "", fmt.Errorf("%w: client_id e client_secret são obrigatórios", model.ErrInvalidServiceAccountAuthentication)
+		}
+		methods = append(methods, model.AuthMethodOIDCClient)
+	}
+	if req.CertificateFingerprint != "" {
+		methods = append(methods, model.AuthMethodMTLSCert)
+	}
+	if req.KubernetesToken != "" || req.KubernetesCluster != "" {
+		if req.KubernetesToken == "" || req.KubernetesCluster == "" {
+			return "", fmt.Errorf("%w: o token Kubernetes exige o cluster", model.ErrInvalidServiceAccountAuthentication)
+		}
+		methods = append(methods, model.AuthMethodKubernetes)
+	}
+	if len(methods) != 1 {
+		return "", fmt.Errorf("%w: indique exatamente um método de autenticação", model.ErrInvalidServiceAccountAuthentication)
+	}
+	return methods[0], nil
+}
+
+// isServiceAccountRejection indica se o erro é uma recusa das credenciais apresentadas e não uma falha interna
+func isServiceAccountRejection(err error) bool {
+	return errors.Is(err, errServiceAccountRejected) ||
+		errors.Is(err, model.ErrServiceAccountCredentialNotFound) ||
+		errors.Is(err, model.ErrServiceAccountNotFound) ||
+		errors.Is(err, model.ErrInvalidWorkloadIdentity)
+}
+
+// parseServiceAccountCertificate lê o certificado mTLS em PEM e exige que ainda seja válido
+func parseServiceAccountCertificate(certificatePEM string, now time.Time) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: o certificado mTLS em PEM é obrigatório", model.ErrInvalidServiceAccountCredential)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: certificado inválido: %v", model.ErrInvalidServiceAccountCredential, err)
+	}
+	if now.Before(certificate.NotBefore) || !now.Before(certificate.NotAfter) {
+		return nil, fmt.Errorf("%w: o certificado não está dentro da validade", model.ErrInvalidServiceAccountCredential)
+	}
+	return certificate, nil
+}
+
+// hashServiceAccountSecret calcula o hash guardado dos segredos gerados, que têm entropia suficiente
+// para dispensar um algoritmo de derivação lento
+func hashServiceAccountSecret(secret string) []byte {
+	digest := sha256.Sum256([]byte(secret))
+	return digest[:]
+}
+
+// randomSecret gera um segredo aleatório de 256 bits, em base64url
+func randomSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar segredo: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// randomHex gera um identificador público aleatório com n bytes, em hexadecimal
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar identificador: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -14,6 +14,7 @@ import (
 	"innovabiz/iam/identity-service/internal/domain/event"
 	"innovabiz/iam/identity-service/internal/domain/model"
 	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
 )
 
 var tracer = otel.Tracer("innovabiz.iam.application.impl")
@@ -114,7 +115,7 @@ func (f *ServiceFactory) startSystemRoleSyncScheduler(service *RoleServiceImpl)
 	
 	go func() {
 		for range ticker.C {
-			ctx := context.Background()
+			ctx := requestctx.WithSystemScope(context.Background(), "system_role_sync")
 			ctx, span := tracer.Start(ctx, "SystemRoleSync.ScheduledSync")
 			
 			log.Info().Msg("Executando sincronização automática de funções do sistema")
@@ -135,7 +136,7 @@ func (f *ServiceFactory) startSystemRoleSyncScheduler(service *RoleServiceImpl)
 			// serviceUserID, _ := uuid.Parse(f.config.Role.SystemRoleSync.ServiceUserID)
 			
 			for _, tenant := range tenants {
-				tenantCtx, tenantSpan := tracer.Start(ctx, "SystemRoleSync.TenantSync", 
+				tenantCtx, tenantSpan := tracer.Start(requestctx.WithTenant(ctx, tenant), "SystemRoleSync.TenantSync", 
 					trace.WithAttributes(attribute.String("tenant_id", tenant.String())))
 				
 				// Tentativa de sincronizar funções do sistema para esta tenant
@@ -0,0 +1,270 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão das políticas de sessão
+const (
+	DefaultSessionPolicyCacheTTL        = time.Minute
+	DefaultSessionClockSkew             = 30 * time.Second
+	DefaultSessionActivityWriteInterval = 30 * time.Second
+	DefaultSessionMaxTrackedSessions    = 100000
+)
+
+// SessionPolicyConfig configura a aplicação das políticas de sessão
+type SessionPolicyConfig struct {
+	// Tempo durante o qual a política de um tenant fica em cache;
+	// as alterações feitas nesta instância invalidam o cache de imediato
+	CacheTTL time.Duration
+	// Tolerância entre os relógios do emissor dos tokens e deste serviço
+	ClockSkew time.Duration
+	// Intervalo mínimo entre registos de atividade da mesma sessão; inferior à inatividade mínima
+	ActivityWriteInterval time.Duration
+	// Sessões cujo último registo é lembrado; ao atingir o limite a memória é esvaziada
+	MaxTrackedSessions int
+}
+
+// DefaultSessionPolicyConfig retorna a configuração padrão das políticas de sessão
+func DefaultSessionPolicyConfig() SessionPolicyConfig {
+	return SessionPolicyConfig{
+		CacheTTL:              DefaultSessionPolicyCacheTTL,
+		ClockSkew:             DefaultSessionClockSkew,
+		ActivityWriteInterval: DefaultSessionActivityWriteInterval,
+		MaxTrackedSessions:    DefaultSessionMaxTrackedSessions,
+	}
+}
+
+// cachedSessionPolicy guarda a política gravada de um tenant, ou nil se não existir
+type cachedSessionPolicy struct {
+	policy    *model.SessionPolicy
+	expiresAt time.Time
+}
+
+// SessionPolicyServiceImpl implementa a interface SessionPolicyService
+type SessionPolicyServiceImpl struct {
+	repository repository.SessionPolicyRepository
+	config     SessionPolicyConfig
+	now        func() time.Time
+
+	mutex    sync.Mutex
+	policies map[uuid.UUID]cachedSessionPolicy
+	// activity guarda o momento do último registo de atividade feito por esta instância
+	activity map[string]time.Time
+}
+
+// NewSessionPolicyService cria uma nova instância de SessionPolicyService
+// Os valores fora do intervalo válido usam os padrões
+func NewSessionPolicyService(repo repository.SessionPolicyRepository, config SessionPolicyConfig) application.SessionPolicyService {
+	defaults := DefaultSessionPolicyConfig()
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaults.CacheTTL
+	}
+	if config.ClockSkew < 0 {
+		config.ClockSkew = defaults.ClockSkew
+	}
+	if config.ActivityWriteInterval <= 0 || config.ActivityWriteInterval >= model.MinSessionIdleTimeout {
+		config.ActivityWriteInterval = defaults.ActivityWriteInterval
+	}
+	if config.MaxTrackedSessions <= 0 {
+		config.MaxTrackedSessions = defaults.MaxTrackedSessions
+	}
+
+	return &SessionPolicyServiceImpl{
+		repository: repo,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+		policies:   make(map[uuid.UUID]cachedSessionPolicy),
+		activity:   make(map[string]time.Time),
+	}
+}
+
+// GetPolicy recupera a política de sessão do tenant ou os valores padrão do mercado
+// Sem política configurada o tenant não é tratado como financeiro
+func (s *SessionPolicyServiceImpl) GetPolicy(ctx context.Context, tenantID uuid.UUID, market string) (*model.SessionPolicy, error) {
+	if tenantID == uuid.Nil {
+		return nil, model.ErrInvalidTenantID
+	}
+
+	now := s.now()
+	s.mutex.Lock()
+	cached, ok := s.policies[tenantID]
+	s.mutex.Unlock()
+
+	if !ok || !now.Before(cached.expiresAt) {
+		policy, err := s.repository.GetPolicy(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar política de sessão: %w", err)
+		}
+		cached = cachedSessionPolicy{policy: policy, expiresAt: now.Add(s.config.CacheTTL)}
+		s.mutex.Lock()
+		s.policies[tenantID] = cached
+		s.mutex.Unlock()
+	}
+
+	if cached.policy == nil {
+		return model.DefaultSessionPolicy(tenantID, market, false), nil
+	}
+	policy := *cached.policy
+	return &policy, nil
+}
+
+// UpdatePolicy altera a política de sessão do tenant
+func (s *SessionPolicyServiceImpl) UpdatePolicy(ctx context.Context, req *application.UpdateSessionPolicyRequest) (*model.SessionPolicy, error) {
+	ctx, span := tracer.Start(ctx, "SessionPolicyServiceImpl.UpdatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("market", req.Market),
+		attribute.Bool("financial_tenant", req.FinancialTenant),
+	))
+	defer span.End()
+
+	if req.AccessTokenTTLSeconds < 0 || req.RefreshTokenTTLSeconds < 0 || req.AbsoluteLifetimeSeconds < 0 || req.IdleTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("%w: os valores não podem ser negativos", model.ErrInvalidSessionPolicy)
+	}
+
+	policy := model.DefaultSessionPolicy(req.TenantID, req.Market, req.FinancialTenant)
+	if req.AccessTokenTTLSeconds > 0 {
+		policy.AccessTokenTTLSeconds = req.AccessTokenTTLSeconds
+	}
+	if req.RefreshTokenTTLSeconds > 0 {
+		policy.RefreshTokenTTLSeconds = req.RefreshTokenTTLSeconds
+	}
+	if req.AbsoluteLifetimeSeconds > 0 {
+		policy.AbsoluteLifetimeSeconds = req.AbsoluteLifetimeSeconds
+	}
+	if req.IdleTimeoutSeconds > 0 {
+		policy.IdleTimeoutSeconds = req.IdleTimeoutSeconds
+	}
+	policy.Default = false
+	policy.UpdatedBy = req.UpdatedBy
+	policy.UpdatedAt = s.now()
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SavePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("erro ao gravar política de sessão: %w", err)
+	}
+	s.invalidate(policy.TenantID)
+
+	log.Info().
+		Str("tenant_id", policy.TenantID.String()).
+		Str("market", policy.Market).
+		Bool("financial_tenant", policy.FinancialTenant).
+		Int("access_token_ttl_seconds", policy.AccessTokenTTLSeconds).
+		Int("refresh_token_ttl_seconds", policy.RefreshTokenTTLSeconds).
+		Int("absolute_lifetime_seconds", policy.AbsoluteLifetimeSeconds).
+		Int("idle_timeout_seconds", policy.IdleTimeoutSeconds).
+		Str("actor_id", policy.UpdatedBy.String()).
+		Msg("Política de sessão alterada")
+	return policy, nil
+}
+
+// ResetPolicy remove a política de sessão do tenant
+func (s *SessionPolicyServiceImpl) ResetPolicy(ctx context.Context, tenantID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionPolicyServiceImpl.ResetPolicy", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	if tenantID == uuid.Nil {
+		return model.ErrInvalidTenantID
+	}
+	if err := s.repository.DeletePolicy(ctx, tenantID); err != nil {
+		return fmt.Errorf("erro ao remover política de sessão: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Política de sessão reposta nos valores padrão")
+	return nil
+}
+
+// Lifetimes calcula a validade dos tokens a emitir; sem momento de autenticação a sessão começa agora
+func (s *SessionPolicyServiceImpl) Lifetimes(ctx context.Context, tenantID uuid.UUID, market string, authTime time.Time) (*model.SessionLifetimes, error) {
+	policy, err := s.GetPolicy(ctx, tenantID, market)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if authTime.IsZero() {
+		authTime = now
+	}
+	return policy.Lifetimes(authTime, now), nil
+}
+
+// CheckSession verifica o pedido contra a política de sessão do tenant
+//
+// A validade do token e a duração da sessão são verificadas em cada pedido. A inatividade só
+// é verificada nas sessões identificadas: o pedido é registado na sessão, no máximo uma vez
+// por ActivityWriteInterval em cada instância, e uma sessão sem pedidos durante mais tempo
+// que a inatividade permitida fica terminada, mesmo que a política seja depois alargada.
+func (s *SessionPolicyServiceImpl) CheckSession(ctx context.Context, check *model.SessionCheck) (*model.SessionDecision, error) {
+	policy, err := s.GetPolicy(ctx, check.TenantID, check.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	decision := policy.Evaluate(check, now, s.config.ClockSkew)
+	if !decision.Allowed || check.SessionID == "" {
+		return &decision, nil
+	}
+
+	key := check.TenantID.String() + "/" + check.SessionID
+	s.mutex.Lock()
+	lastWrite, tracked := s.activity[key]
+	s.mutex.Unlock()
+	if tracked && now.Sub(lastWrite) < s.config.ActivityWriteInterval {
+		return &decision, nil
+	}
+
+	startedAt := check.AuthTime
+	if startedAt.IsZero() {
+		startedAt = now
+	}
+	activity, err := s.repository.TouchSession(ctx, &model.SessionActivity{
+		TenantID:   check.TenantID,
+		SessionID:  check.SessionID,
+		UserID:     check.UserID,
+		StartedAt:  startedAt,
+		LastSeenAt: now,
+	}, now.Add(-(policy.IdleTimeout() + s.config.ClockSkew)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registar atividade da sessão: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if activity.EndedAt != nil {
+		delete(s.activity, key)
+		return &model.SessionDecision{Reason: model.SessionRejectIdle, SessionExpiresAt: decision.SessionExpiresAt}, nil
+	}
+	if len(s.activity) >= s.config.MaxTrackedSessions {
+		s.activity = make(map[string]time.Time)
+	}
+	s.activity[key] = now
+	return &decision, nil
+}
+
+// invalidate descarta o cache do tenant após uma alteração
+func (s *SessionPolicyServiceImpl) invalidate(tenantID uuid.UUID) {
+	s.mutex.Lock()
+	delete(s.policies, tenantID)
+	s.mutex.Unlock()
+}
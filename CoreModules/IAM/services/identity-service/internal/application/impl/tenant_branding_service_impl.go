@@ -0,0 +1,393 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// DefaultEmailTemplateListLimit é o número máximo de versões retornadas na listagem dos modelos
+const DefaultEmailTemplateListLimit = 200
+
+// TenantBrandingServiceImpl implementa a interface TenantBrandingService
+type TenantBrandingServiceImpl struct {
+	repository repository.TenantBrandingRepository
+	now        func() time.Time
+}
+
+// NewTenantBrandingService cria uma nova instância de TenantBrandingService
+func NewTenantBrandingService(repo repository.TenantBrandingRepository) application.TenantBrandingService {
+	return &TenantBrandingServiceImpl{
+		repository: repo,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GetBranding recupera a identidade visual do tenant
+// Os tenants que nunca a configuraram usam a identidade visual padrão
+func (s *TenantBrandingServiceImpl) GetBranding(ctx context.Context, tenantID uuid.UUID) (*model.TenantBranding, error) {
+	branding, err := s.repository.GetBranding(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter identidade visual do tenant: %w", err)
+	}
+	if branding == nil {
+		return model.DefaultTenantBranding(tenantID), nil
+	}
+	return branding, nil
+}
+
+// UpdateBranding valida e grava a identidade visual do tenant
+func (s *TenantBrandingServiceImpl) UpdateBranding(ctx context.Context, req *application.UpdateTenantBrandingRequest) (*model.TenantBranding, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingServiceImpl.UpdateBranding", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+	))
+	defer span.End()
+
+	branding := &model.TenantBranding{
+		TenantID:       req.TenantID,
+		DisplayName:    req.DisplayName,
+		LogoURL:        strings.TrimSpace(req.LogoURL),
+		PrimaryColor:   strings.TrimSpace(req.PrimaryColor),
+		SecondaryColor: strings.TrimSpace(req.SecondaryColor),
+		SupportEmail:   strings.TrimSpace(req.SupportEmail),
+		SupportURL:     strings.TrimSpace(req.SupportURL),
+		FooterText:     strings.TrimSpace(req.FooterText),
+		UpdatedBy:      req.UpdatedBy,
+		UpdatedAt:      s.now(),
+	}
+	if err := branding.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SaveBranding(ctx, branding); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar identidade visual do tenant: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", branding.TenantID.String()).
+		Str("display_name", branding.DisplayName).
+		Str("updated_by", branding.UpdatedBy.String()).
+		Msg("Identidade visual do tenant atualizada")
+
+	return branding, nil
+}
+
+// ListVariables recupera as variáveis disponíveis nos modelos de cada email
+func (s *TenantBrandingServiceImpl) ListVariables(ctx context.Context) []application.EmailTemplateKindVariables {
+	kinds := make([]application.EmailTemplateKindVariables, 0, len(model.EmailTemplateKinds))
+	for _, kind := range model.EmailTemplateKinds {
+		kinds = append(kinds, application.EmailTemplateKindVariables{
+			Kind:      kind,
+			Variables: model.EmailTemplateVariables(kind),
+		})
+	}
+	return kinds
+}
+
+// ListEmailTemplates recupera as versões dos modelos do tenant
+func (s *TenantBrandingServiceImpl) ListEmailTemplates(ctx context.Context, req *application.ListEmailTemplatesRequest) ([]*model.EmailTemplate, error) {
+	filter := repository.EmailTemplateFilter{
+		Kind:   req.Kind,
+		Status: req.Status,
+		Limit:  DefaultEmailTemplateListLimit,
+	}
+	if req.Kind != "" && !req.Kind.IsValid() {
+		return nil, fmt.Errorf("%w: email desconhecido %q", model.ErrInvalidEmailTemplate, req.Kind)
+	}
+	switch req.Status {
+	case "", model.EmailTemplateDraft, model.EmailTemplatePublished, model.EmailTemplateArchived:
+	default:
+		return nil, fmt.Errorf("%w: estado desconhecido %q", model.ErrInvalidEmailTemplate, req.Status)
+	}
+	if req.Locale != "" {
+		if filter.Locale = model.NormalizeLoginNotificationLocale(req.Locale); filter.Locale == "" {
+			return nil, fmt.Errorf("%w: idioma inválido", model.ErrInvalidEmailTemplate)
+		}
+	}
+
+	templates, err := s.repository.ListEmailTemplates(ctx, req.TenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar modelos de email: %w", err)
+	}
+	return templates, nil
+}
+
+// GetEmailTemplate recupera uma versão de um modelo do tenant
+func (s *TenantBrandingServiceImpl) GetEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error) {
+	template, err := s.repository.GetEmailTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		if errors.Is(err, model.ErrEmailTemplateNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter modelo de email: %w", err)
+	}
+	return template, nil
+}
+
+// CreateEmailTemplate valida, sanitiza e grava uma nova versão como rascunho
+// O HTML gravado é o HTML sanitizado, para que a pré-visualização mostre o que será enviado
+func (s *TenantBrandingServiceImpl) CreateEmailTemplate(ctx context.Context, req *application.CreateEmailTemplateRequest) (*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingServiceImpl.CreateEmailTemplate", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("kind", string(req.Kind)),
+	))
+	defer span.End()
+
+	template := &model.EmailTemplate{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		Kind:        req.Kind,
+		Locale:      req.Locale,
+		Status:      model.EmailTemplateDraft,
+		Description: strings.TrimSpace(req.Description),
+		Subject:     req.Subject,
+		HTMLBody:    req.HTMLBody,
+		TextBody:    req.TextBody,
+		CreatedBy:   req.CreatedBy,
+		CreatedAt:   s.now(),
+	}
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreateEmailTemplate(ctx, template); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar modelo de email: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", template.TenantID.String()).
+		Str("template_id", template.ID.String()).
+		Str("kind", string(template.Kind)).
+		Str("locale", template.Locale).
+		Int("version", template.Version).
+		Str("created_by", template.CreatedBy.String()).
+		Msg("Versão de modelo de email gravada")
+
+	return template, nil
+}
+
+// PublishEmailTemplate publica uma versão no lugar da versão publicada do mesmo email e idioma
+// Publicar a versão já publicada não tem efeito
+func (s *TenantBrandingServiceImpl) PublishEmailTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) (*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingServiceImpl.PublishEmailTemplate", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("template_id", templateID.String()),
+	))
+	defer span.End()
+
+	current, err := s.GetEmailTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == model.EmailTemplatePublished {
+		return current, nil
+	}
+	// As versões gravadas antes de uma alteração das variáveis ou da sanitização são revalidadas
+	revalidated := *current
+	if err := revalidated.Validate(); err != nil {
+		return nil, err
+	}
+
+	template, err := s.repository.PublishEmailTemplate(ctx, tenantID, templateID, actorID, s.now())
+	if err != nil {
+		if errors.Is(err, model.ErrEmailTemplateNotFound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao publicar modelo de email: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("template_id", template.ID.String()).
+		Str("kind", string(template.Kind)).
+		Str("locale", template.Locale).
+		Int("version", template.Version).
+		Bool("rollback", current.Status == model.EmailTemplateArchived).
+		Str("published_by", actorID.String()).
+		Msg("Versão de modelo de email publicada")
+
+	return template, nil
+}
+
+// ArchiveEmailTemplate arquiva uma versão; arquivar a versão publicada repõe o modelo padrão
+// Arquivar uma versão já arquivada não tem efeito
+func (s *TenantBrandingServiceImpl) ArchiveEmailTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) (*model.EmailTemplate, error) {
+	current, err := s.GetEmailTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == model.EmailTemplateArchived {
+		return current, nil
+	}
+
+	template, err := s.repository.ArchiveEmailTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		if errors.Is(err, model.ErrEmailTemplateNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao arquivar modelo de email: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("template_id", template.ID.String()).
+		Str("kind", string(template.Kind)).
+		Str("locale", template.Locale).
+		Int("version", template.Version).
+		Bool("was_published", current.Status == model.EmailTemplatePublished).
+		Str("archived_by", actorID.String()).
+		Msg("Versão de modelo de email arquivada")
+
+	return template, nil
+}
+
+// PreviewEmail produz um email com valores de exemplo sem o enviar
+func (s *TenantBrandingServiceImpl) PreviewEmail(ctx context.Context, req *application.PreviewEmailRequest) (*model.RenderedEmail, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingServiceImpl.PreviewEmail", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+	))
+	defer span.End()
+
+	var (
+		template *model.EmailTemplate
+		err      error
+	)
+	switch {
+	case req.TemplateID != nil:
+		if template, err = s.GetEmailTemplate(ctx, req.TenantID, *req.TemplateID); err != nil {
+			return nil, err
+		}
+	case req.HTMLBody != "":
+		template = &model.EmailTemplate{
+			TenantID: req.TenantID,
+			Kind:     req.Kind,
+			Locale:   req.Locale,
+			Status:   model.EmailTemplateDraft,
+			Subject:  req.Subject,
+			HTMLBody: req.HTMLBody,
+			TextBody: req.TextBody,
+		}
+		if err := template.Validate(); err != nil {
+			return nil, err
+		}
+	default:
+		if !req.Kind.IsValid() {
+			return nil, fmt.Errorf("%w: email desconhecido %q", model.ErrInvalidEmailTemplate, req.Kind)
+		}
+		if template, err = s.activeTemplate(ctx, req.TenantID, req.Kind, req.Locale); err != nil {
+			return nil, err
+		}
+	}
+
+	data := model.SampleEmailTemplateData()
+	for name, value := range req.Variables {
+		if err := data.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	branding := req.Branding
+	if branding != nil {
+		branding.TenantID = req.TenantID
+		if err := branding.Validate(); err != nil {
+			return nil, err
+		}
+	} else if branding, err = s.GetBranding(ctx, req.TenantID); err != nil {
+		return nil, err
+	}
+
+	rendered, err := template.Render(data, branding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidEmailTemplate, err)
+	}
+	// O modelo enviado na pré-visualização não é o modelo padrão, embora não tenha versão
+	rendered.Builtin = rendered.Builtin && req.HTMLBody == ""
+	return rendered, nil
+}
+
+// RenderEmail produz o email no idioma a partir da versão publicada pelo tenant ou do modelo padrão
+//
+// Uma versão publicada que já não compila, por exemplo após a remoção de uma variável, é
+// substituída pelo modelo padrão para que o fluxo de autoatendimento não fique bloqueado.
+func (s *TenantBrandingServiceImpl) RenderEmail(ctx context.Context, tenantID uuid.UUID, kind model.EmailTemplateKind, locale string, data *model.EmailTemplateData) (*model.RenderedEmail, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingServiceImpl.RenderEmail", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("kind", string(kind)),
+	))
+	defer span.End()
+
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("%w: email desconhecido %q", model.ErrInvalidEmailTemplate, kind)
+	}
+	template, err := s.activeTemplate(ctx, tenantID, kind, locale)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	branding, err := s.GetBranding(ctx, tenantID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	rendered, err := template.Render(data, branding)
+	if err != nil && template.ID != uuid.Nil {
+		log.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("template_id", template.ID.String()).
+			Int("version", template.Version).
+			Msg("Erro ao produzir email com o modelo publicado; usado o modelo padrão")
+		rendered, err = model.BuiltinEmailTemplate(kind, template.Locale).Render(data, branding)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao produzir email: %w", err)
+	}
+	return rendered, nil
+}
+
+// activeTemplate escolhe a versão publicada do email no idioma, preferindo o idioma exato à mesma
+// língua. Sem versão publicada, retorna o modelo padrão
+func (s *TenantBrandingServiceImpl) activeTemplate(ctx context.Context, tenantID uuid.UUID, kind model.EmailTemplateKind, locale string) (*model.EmailTemplate, error) {
+	if locale = model.NormalizeLoginNotificationLocale(locale); locale == "" {
+		locale = model.DefaultLoginNotificationLocale
+	}
+
+	published, err := s.repository.ListEmailTemplates(ctx, tenantID, repository.EmailTemplateFilter{
+		Kind:   kind,
+		Status: model.EmailTemplatePublished,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter modelos de email publicados: %w", err)
+	}
+
+	language := strings.SplitN(locale, "-", 2)[0]
+	var sameLanguage *model.EmailTemplate
+	for _, template := range published {
+		if template.Locale == locale {
+			return template, nil
+		}
+		if sameLanguage == nil && strings.SplitN(template.Locale, "-", 2)[0] == language {
+			sameLanguage = template
+		}
+	}
+	if sameLanguage != nil {
+		return sameLanguage, nil
+	}
+	return model.BuiltinEmailTemplate(kind, locale), nil
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre passagens de geração das exportações de tenants
+const DefaultTenantExportInterval = time.Minute
+
+// TenantExportScheduler gera periodicamente as exportações pendentes ou interrompidas
+type TenantExportScheduler struct {
+	service  application.TenantExportService
+	interval time.Duration
+}
+
+// NewTenantExportScheduler cria o agendador da geração das exportações
+// Um intervalo não positivo usa DefaultTenantExportInterval
+func NewTenantExportScheduler(service application.TenantExportService, interval time.Duration) *TenantExportScheduler {
+	if interval <= 0 {
+		interval = DefaultTenantExportInterval
+	}
+	return &TenantExportScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start gera as exportações no arranque e a cada intervalo até o contexto ser cancelado
+// As exportações interrompidas pelo cancelamento são retomadas no arranque seguinte
+func (s *TenantExportScheduler) Start(ctx context.Context) {
+	// As exportações pendentes pertencem a vários tenants
+	ctx = requestctx.WithSystemScope(ctx, "tenant_export_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *TenantExportScheduler) run(ctx context.Context) {
+	result, err := s.service.RunPending(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao gerar exportações de tenants")
+		return
+	}
+	if result.Processed == 0 {
+		return
+	}
+
+	log.Info().
+		Int("processed", result.Processed).
+		Int("completed", result.Completed).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Passagem de geração de exportações de tenants concluída")
+}
@@ -0,0 +1,619 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da exportação de dados de tenants
+const (
+	DefaultTenantExportBatchSize = 1000
+	DefaultTenantExportRunLimit  = 10
+)
+
+// Pseudónimos dos dados pessoais no arquivo
+const (
+	tenantExportPseudonymPrefix = "pseud:"
+	tenantExportPseudonymLength = 16
+)
+
+// TenantExportConfig configura a geração dos arquivos de exportação
+type TenantExportConfig struct {
+	// Número de linhas lidas e gravadas por lote; o progresso é confirmado após cada lote
+	BatchSize int
+	// Número máximo de exportações geradas por passagem do agendador
+	RunLimit int
+	// Chave Ed25519 que assina os manifestos e o identificador publicado com ela
+	SigningKey ed25519.PrivateKey
+	KeyID      string
+	// Chave HMAC da pseudonimização; sem chave, é derivada da chave de assinatura.
+	// Cada exportação usa uma chave própria derivada desta, pelo que os pseudónimos
+	// são estáveis dentro do arquivo mas não correlacionáveis entre exportações
+	PseudonymizationKey []byte
+}
+
+// TenantExportServiceImpl implementa a interface TenantExportService
+type TenantExportServiceImpl struct {
+	repository repository.TenantExportRepository
+	archive    application.TenantExportArchive
+	config     TenantExportConfig
+
+	now func() time.Time
+}
+
+// NewTenantExportService cria uma nova instância de TenantExportService
+func NewTenantExportService(
+	repo repository.TenantExportRepository,
+	archive application.TenantExportArchive,
+	config TenantExportConfig,
+) application.TenantExportService {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultTenantExportBatchSize
+	}
+	if config.RunLimit <= 0 {
+		config.RunLimit = DefaultTenantExportRunLimit
+	}
+	if len(config.PseudonymizationKey) == 0 && len(config.SigningKey) == ed25519.PrivateKeySize {
+		mac := hmac.New(sha256.New, config.SigningKey.Seed())
+		mac.Write([]byte("tenant-export-pseudonymization"))
+		config.PseudonymizationKey = mac.Sum(nil)
+	}
+
+	return &TenantExportServiceImpl{
+		repository: repo,
+		archive:    archive,
+		config:     config,
+		now:        time.Now,
+	}
+}
+
+// Start regista uma exportação pendente
+func (s *TenantExportServiceImpl) Start(ctx context.Context, req *application.StartTenantExportRequest) (*model.TenantExport, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportServiceImpl.Start", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("market", req.Market),
+		attribute.String("format", string(req.Format)),
+	))
+	defer span.End()
+
+	export, err := model.NewTenantExport(req.TenantID, req.RequestedBy, req.Market, req.Format, req.PIIMode, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, export); err != nil {
+		if errors.Is(err, model.ErrTenantExportInProgress) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao registar exportação de tenant: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", export.TenantID.String()).
+		Str("export_id", export.ID.String()).
+		Str("requested_by", export.RequestedBy.String()).
+		Str("market", export.Market).
+		Str("pii_mode", string(export.PIIMode)).
+		Msg("Exportação de tenant registada")
+
+	return export, nil
+}
+
+// Get recupera uma exportação pelo ID
+func (s *TenantExportServiceImpl) Get(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error) {
+	export, err := s.repository.Get(ctx, tenantID, exportID)
+	if err != nil {
+		if errors.Is(err, model.ErrTenantExportNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter exportação de tenant: %w", err)
+	}
+	return export, nil
+}
+
+// List recupera as exportações do tenant
+func (s *TenantExportServiceImpl) List(ctx context.Context, tenantID uuid.UUID) ([]*model.TenantExport, error) {
+	exports, err := s.repository.List(ctx, tenantID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar exportações de tenant: %w", err)
+	}
+	return exports, nil
+}
+
+// Resume devolve à fila uma exportação falhada
+func (s *TenantExportServiceImpl) Resume(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error) {
+	export, err := s.Get(ctx, tenantID, exportID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := export.Version
+	if err := export.Resume(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.save(ctx, export, version); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("tenant_id", export.TenantID.String()).
+		Str("export_id", export.ID.String()).
+		Msg("Exportação de tenant retomada")
+
+	return export, nil
+}
+
+// Run gera a exportação lote a lote, confirmando o progresso após cada lote
+// O cancelamento do contexto interrompe a geração sem a marcar como falhada
+func (s *TenantExportServiceImpl) Run(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportServiceImpl.Run", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("export_id", exportID.String()),
+	))
+	defer span.End()
+
+	export, err := s.Get(ctx, tenantID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status == model.TenantExportStatusCompleted {
+		return export, nil
+	}
+
+	if err := export.Start(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.save(ctx, export, export.Version); err != nil {
+		return nil, err
+	}
+
+	// Descartar o que foi escrito após o último lote confirmado
+	if section := export.CurrentSection(); section != nil {
+		name := section.Section.FileName(export.Format)
+		if err := s.archive.Truncate(ctx, export.ID, name, section.Bytes); err != nil {
+			return s.fail(ctx, span, export, fmt.Errorf("erro ao repor o ficheiro %s: %w", name, err))
+		}
+	}
+
+	pseudonymKey := s.pseudonymizationKey(export.ID)
+	for section := export.CurrentSection(); section != nil; section = export.CurrentSection() {
+		if err := ctx.Err(); err != nil {
+			return export, err
+		}
+		if err := s.writeBatch(ctx, export, section, pseudonymKey); err != nil {
+			return s.fail(ctx, span, export, err)
+		}
+		if err := s.save(ctx, export, export.Version); err != nil {
+			return export, err
+		}
+	}
+
+	manifest, err := s.writeManifest(ctx, export)
+	if err != nil {
+		return s.fail(ctx, span, export, err)
+	}
+	export.Complete(manifest, s.now())
+	if err := s.save(ctx, export, export.Version); err != nil {
+		return export, err
+	}
+
+	log.Info().
+		Str("tenant_id", export.TenantID.String()).
+		Str("export_id", export.ID.String()).
+		Int("files", len(manifest.Files)).
+		Int("attempts", export.Attempts).
+		Msg("Exportação de tenant concluída")
+
+	return export, nil
+}
+
+// RunPending gera as exportações pendentes ou interrompidas de todos os tenants
+func (s *TenantExportServiceImpl) RunPending(ctx context.Context) (*application.TenantExportRunResult, error) {
+	result := &application.TenantExportRunResult{StartedAt: s.now()}
+
+	exports, err := s.repository.ListActive(ctx, s.config.RunLimit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar exportações pendentes: %w", err)
+	}
+
+	for _, pending := range exports {
+		if ctx.Err() != nil {
+			break
+		}
+
+		export, err := s.Run(ctx, pending.TenantID, pending.ID)
+		if errors.Is(err, model.ErrTenantExportConflict) {
+			// Outra instância está a gerar a exportação
+			continue
+		}
+		result.Processed++
+		switch {
+		case err == nil && export.Status == model.TenantExportStatusCompleted:
+			result.Completed++
+		case export != nil && export.Status == model.TenantExportStatusFailed:
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// OpenFile abre um ficheiro de uma exportação concluída
+func (s *TenantExportServiceImpl) OpenFile(ctx context.Context, tenantID, exportID uuid.UUID, name string) (io.ReadCloser, error) {
+	export, err := s.Get(ctx, tenantID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.Status != model.TenantExportStatusCompleted || export.Manifest == nil {
+		return nil, fmt.Errorf("%w: estado atual %s", model.ErrTenantExportNotReady, export.Status)
+	}
+	if _, ok := export.Manifest.File(name); !ok && name != model.TenantExportManifestFileName {
+		return nil, fmt.Errorf("%w: ficheiro %q", model.ErrTenantExportNotFound, name)
+	}
+
+	file, err := s.archive.Open(ctx, export.ID, name)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir ficheiro %s da exportação: %w", name, err)
+	}
+	return file, nil
+}
+
+// SigningKey retorna a chave pública que verifica os manifestos
+func (s *TenantExportServiceImpl) SigningKey() application.TenantExportSigningKey {
+	key := application.TenantExportSigningKey{
+		KeyID:     s.config.KeyID,
+		Algorithm: model.TenantExportSignatureEd25519,
+	}
+	if publicKey, ok := s.config.SigningKey.Public().(ed25519.PublicKey); ok {
+		key.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	}
+	return key
+}
+
+// writeBatch lê o lote seguinte da secção, acrescenta-o ao ficheiro e atualiza o progresso
+func (s *TenantExportServiceImpl) writeBatch(
+	ctx context.Context,
+	export *model.TenantExport,
+	section *model.TenantExportSectionProgress,
+	pseudonymKey []byte,
+) error {
+	records, err := s.repository.ReadSection(ctx, export.TenantID, section.Section, section.Cursor, s.config.BatchSize)
+	if err != nil {
+		return fmt.Errorf("erro ao ler secção %s: %w", section.Section, err)
+	}
+
+	columns := section.Section.Columns()
+	encoder := tenantExportEncoder{columns: columns, mode: export.PIIMode, key: pseudonymKey}
+	var buf bytes.Buffer
+	if export.Format == model.TenantExportFormatCSV {
+		writer := csv.NewWriter(&buf)
+		if section.Bytes == 0 {
+			header := make([]string, len(columns))
+			for i, column := range columns {
+				header[i] = column.Name
+			}
+			writer.Write(header)
+		}
+		for _, record := range records {
+			writer.Write(encoder.csvRow(record))
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("erro ao codificar secção %s: %w", section.Section, err)
+		}
+	} else {
+		for _, record := range records {
+			line, err := encoder.jsonLine(record)
+			if err != nil {
+				return fmt.Errorf("erro ao codificar secção %s: %w", section.Section, err)
+			}
+			buf.Write(line)
+		}
+	}
+
+	name := section.Section.FileName(export.Format)
+	size, err := s.archive.Append(ctx, export.ID, name, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("erro ao gravar o ficheiro %s: %w", name, err)
+	}
+
+	section.Bytes = size
+	section.Records += int64(len(records))
+	if len(records) > 0 {
+		section.Cursor = records[len(records)-1].Key
+	}
+	section.Done = len(records) < s.config.BatchSize
+	export.UpdatedAt = s.now()
+	return nil
+}
+
+// writeManifest calcula o resumo de cada ficheiro, assina o manifesto e grava-o no arquivo
+func (s *TenantExportServiceImpl) writeManifest(ctx context.Context, export *model.TenantExport) (*model.TenantExportManifest, error) {
+	manifest := &model.TenantExportManifest{
+		Version:            model.TenantExportManifestVersion,
+		ExportID:           export.ID,
+		TenantID:           export.TenantID,
+		Market:             export.Market,
+		Format:             export.Format,
+		PIIMode:            export.PIIMode,
+		GeneratedAt:        s.now().UTC(),
+		SignatureAlgorithm: model.TenantExportSignatureEd25519,
+		KeyID:              s.config.KeyID,
+	}
+
+	for _, section := range export.Progress {
+		name := section.Section.FileName(export.Format)
+		digest, size, err := s.digest(ctx, export.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		if size != section.Bytes {
+			return nil, fmt.Errorf("ficheiro %s com %d bytes, esperados %d", name, size, section.Bytes)
+		}
+		manifest.Files = append(manifest.Files, model.TenantExportManifestFile{
+			Name:    name,
+			Section: section.Section,
+			Records: section.Records,
+			Bytes:   size,
+			SHA256:  digest,
+		})
+	}
+
+	payload, err := manifest.SigningPayload()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar manifesto: %w", err)
+	}
+	if len(s.config.SigningKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("chave de assinatura dos manifestos não configurada")
+	}
+	manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.config.SigningKey, payload))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar manifesto: %w", err)
+	}
+	if err := s.archive.Truncate(ctx, export.ID, model.TenantExportManifestFileName, 0); err != nil {
+		return nil, fmt.Errorf("erro ao gravar manifesto: %w", err)
+	}
+	if _, err := s.archive.Append(ctx, export.ID, model.TenantExportManifestFileName, data); err != nil {
+		return nil, fmt.Errorf("erro ao gravar manifesto: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// digest calcula o SHA-256 e o tamanho de um ficheiro do arquivo
+func (s *TenantExportServiceImpl) digest(ctx context.Context, exportID uuid.UUID, name string) (string, int64, error) {
+	file, err := s.archive.Open(ctx, exportID, name)
+	if err != nil {
+		return "", 0, fmt.Errorf("erro ao abrir o ficheiro %s: %w", name, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("erro ao ler o ficheiro %s: %w", name, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// fail regista a falha da passagem; a exportação mantém o progresso do último lote confirmado
+func (s *TenantExportServiceImpl) fail(ctx context.Context, span trace.Span, export *model.TenantExport, cause error) (*model.TenantExport, error) {
+	span.SetStatus(codes.Error, cause.Error())
+	span.RecordError(cause)
+
+	if ctx.Err() != nil {
+		return export, cause
+	}
+
+	export.Fail(cause.Error(), s.now())
+	if err := s.save(ctx, export, export.Version); err != nil {
+		log.Error().Err(err).
+			Str("export_id", export.ID.String()).
+			Msg("Erro ao registar falha da exportação de tenant")
+	}
+
+	log.Error().Err(cause).
+		Str("tenant_id", export.TenantID.String()).
+		Str("export_id", export.ID.String()).
+		Msg("Falha na geração da exportação de tenant")
+
+	return export, cause
+}
+
+// save grava a exportação, preservando o erro de conflito entre instâncias
+func (s *TenantExportServiceImpl) save(ctx context.Context, export *model.TenantExport, version int64) error {
+	if err := s.repository.Save(ctx, export, version); err != nil {
+		if errors.Is(err, model.ErrTenantExportConflict) {
+			return err
+		}
+		return fmt.Errorf("erro ao gravar exportação de tenant: %w", err)
+	}
+	return nil
+}
+
+// pseudonymizationKey deriva a chave de pseudonimização própria da exportação
+func (s *TenantExportServiceImpl) pseudonymizationKey(exportID uuid.UUID) []byte {
+	mac := hmac.New(sha256.New, s.config.PseudonymizationKey)
+	mac.Write(exportID[:])
+	return mac.Sum(nil)
+}
+
+// tenantExportEncoder codifica as linhas de uma secção aplicando o tratamento dos dados pessoais
+type tenantExportEncoder struct {
+	columns []model.TenantExportColumn
+	mode    model.TenantExportPIIMode
+	key     []byte
+}
+
+// jsonLine codifica a linha como um objeto JSON com os campos pela ordem das colunas
+func (e tenantExportEncoder) jsonLine(record model.TenantExportRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(column.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(e.value(column, record.Values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("coluna %s: %w", column.Name, err)
+		}
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// csvRow codifica a linha como campos CSV; valores nulos ficam vazios
+func (e tenantExportEncoder) csvRow(record model.TenantExportRecord) []string {
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		switch value := e.value(column, record.Values[i]).(type) {
+		case nil:
+		case string:
+			row[i] = value
+		case json.RawMessage:
+			row[i] = string(value)
+		case time.Time:
+			row[i] = value.UTC().Format(time.RFC3339Nano)
+		case bool:
+			row[i] = strconv.FormatBool(value)
+		default:
+			row[i] = fmt.Sprint(value)
+		}
+	}
+	return row
+}
+
+// value aplica o tratamento dos dados pessoais ao valor da coluna
+func (e tenantExportEncoder) value(column model.TenantExportColumn, value interface{}) interface{} {
+	if e.mode == model.TenantExportPIIInclude || value == nil {
+		return value
+	}
+
+	switch {
+	case column.PII:
+		if text, ok := value.(string); ok {
+			return e.protect(column.Name, text)
+		}
+	case column.Structured:
+		if raw, ok := value.(json.RawMessage); ok {
+			return e.protectStructured(raw)
+		}
+	}
+	return value
+}
+
+// protectStructured trata os campos pessoais de um valor JSON, a qualquer profundidade
+func (e tenantExportEncoder) protectStructured(raw json.RawMessage) json.RawMessage {
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return raw
+	}
+
+	protected, err := json.Marshal(e.walk(decoded))
+	if err != nil {
+		return raw
+	}
+	return protected
+}
+
+// walk percorre o valor JSON tratando os campos pessoais conhecidos
+func (e tenantExportEncoder) walk(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if text, ok := field.(string); ok && isTenantExportPIIField(key) {
+				typed[key] = e.protect(key, text)
+				continue
+			}
+			typed[key] = e.walk(field)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = e.walk(item)
+		}
+	}
+	return value
+}
+
+// protect oculta ou pseudonimiza um dado pessoal
+func (e tenantExportEncoder) protect(field, value string) string {
+	if value == "" {
+		return value
+	}
+	if e.mode == model.TenantExportPIIPseudonymize {
+		mac := hmac.New(sha256.New, e.key)
+		mac.Write([]byte(value))
+		return tenantExportPseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:tenantExportPseudonymLength]
+	}
+	return maskTenantExportValue(field, value)
+}
+
+// maskTenantExportValue mantém apenas a parte do valor necessária à sua identificação pelo tenant
+func maskTenantExportValue(field, value string) string {
+	switch {
+	case strings.Contains(field, "email"):
+		if at := strings.LastIndex(value, "@"); at > 0 {
+			return firstRune(value) + "***" + value[at:]
+		}
+	case strings.HasPrefix(field, "phone"):
+		if runes := []rune(value); len(runes) > 4 {
+			return "***" + string(runes[len(runes)-2:])
+		}
+		return "***"
+	case field == "ip_address":
+		if dot := strings.Index(value, "."); dot > 0 {
+			return value[:dot] + ".***"
+		}
+		return "***"
+	}
+	return firstRune(value) + "***"
+}
+
+// firstRune retorna o primeiro carácter do valor
+func firstRune(value string) string {
+	for _, r := range value {
+		return string(r)
+	}
+	return ""
+}
+
+// isTenantExportPIIField indica se o campo de um valor estruturado é um dado pessoal
+func isTenantExportPIIField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range model.TenantExportPIIFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,472 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de pedidos de acesso (AccessRequestService).
+ * Valida o registo dos pedidos, a autoridade dos aprovadores, a concessão automática
+ * na aprovação e as métricas de prazo de decisão.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeAccessRequestRepository é um AccessRequestRepository em memória
+type fakeAccessRequestRepository struct {
+	mu          sync.Mutex
+	requests    map[uuid.UUID]*model.AccessRequest
+	approvers   []*model.AccessRequestApprover
+	permissions map[uuid.UUID]bool
+	grants      map[uuid.UUID]*time.Time
+	grantErr    error
+}
+
+func newFakeAccessRequestRepository() *fakeAccessRequestRepository {
+	return &fakeAccessRequestRepository{
+		requests:    make(map[uuid.UUID]*model.AccessRequest),
+		permissions: make(map[uuid.UUID]bool),
+		grants:      make(map[uuid.UUID]*time.Time),
+	}
+}
+
+func (r *fakeAccessRequestRepository) status(requestID uuid.UUID) model.AccessRequestStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.requests[requestID].Status
+}
+
+func (r *fakeAccessRequestRepository) Create(ctx context.Context, req *model.AccessRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.requests {
+		if existing.IsPending() && existing.TenantID == req.TenantID && existing.RequesterID == req.RequesterID &&
+			existing.TargetType == req.TargetType && existing.TargetID == req.TargetID {
+			return model.ErrDuplicateAccessRequest
+		}
+	}
+	copied := *req
+	r.requests[req.ID] = &copied
+	return nil
+}
+
+func (r *fakeAccessRequestRepository) Get(ctx context.Context, tenantID, requestID uuid.UUID) (*model.AccessRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	req, ok := r.requests[requestID]
+	if !ok || req.TenantID != tenantID {
+		return nil, model.ErrAccessRequestNotFound
+	}
+	copied := *req
+	return &copied, nil
+}
+
+func (r *fakeAccessRequestRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.AccessRequestFilter) ([]*model.AccessRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.AccessRequest
+	for _, req := range r.requests {
+		if req.TenantID != tenantID {
+			continue
+		}
+		if filter.Status != "" && req.Status != filter.Status {
+			continue
+		}
+		if filter.RequesterID != nil && req.RequesterID != *filter.RequesterID {
+			continue
+		}
+		if filter.RolesOnly && req.TargetType != model.AccessRequestTargetRole {
+			continue
+		}
+		if len(filter.RoleIDs) > 0 && req.TargetType == model.AccessRequestTargetRole && !containsID(filter.RoleIDs, req.TargetID) {
+			continue
+		}
+		if filter.DecidedSince != nil && (req.DecidedAt == nil || req.DecidedAt.Before(*filter.DecidedSince)) {
+			continue
+		}
+		copied := *req
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (r *fakeAccessRequestRepository) SaveDecision(ctx context.Context, req *model.AccessRequest, expected model.AccessRequestStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.requests[req.ID]
+	if !ok || stored.Status != expected {
+		return model.ErrAccessRequestConflict
+	}
+	copied := *req
+	r.requests[req.ID] = &copied
+	return nil
+}
+
+func (r *fakeAccessRequestRepository) PermissionExists(ctx context.Context, tenantID, permissionID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.permissions[permissionID], nil
+}
+
+func (r *fakeAccessRequestRepository) GrantPermission(ctx context.Context, tenantID, userID, permissionID uuid.UUID, expiresAt *time.Time, grantedBy, requestID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.grantErr != nil {
+		return r.grantErr
+	}
+	r.grants[permissionID] = expiresAt
+	return nil
+}
+
+func (r *fakeAccessRequestRepository) ListApprovers(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.AccessRequestApprover, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.AccessRequestApprover
+	for _, approver := range r.approvers {
+		if approver.TenantID == tenantID && (userID == nil || approver.UserID == *userID) {
+			result = append(result, approver)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAccessRequestRepository) SaveApprover(ctx context.Context, approver *model.AccessRequestApprover) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.approvers = append(r.approvers, approver)
+	return nil
+}
+
+func (r *fakeAccessRequestRepository) DeleteApprover(ctx context.Context, tenantID, approverID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, approver := range r.approvers {
+		if approver.TenantID == tenantID && approver.ID == approverID {
+			r.approvers = append(r.approvers[:i], r.approvers[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrAccessApproverNotFound
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeGrantingRoleService é um RoleService em memória com as operações usadas pelos pedidos de acesso
+type fakeGrantingRoleService struct {
+	application.RoleService
+	mu          sync.Mutex
+	roles       map[uuid.UUID]*model.Role
+	assignments map[uuid.UUID]map[uuid.UUID]*time.Time
+}
+
+func (f *fakeGrantingRoleService) GetRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error) {
+	role, ok := f.roles[roleID]
+	if !ok {
+		return nil, application.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (f *fakeGrantingRoleService) GetUserActiveRoles(ctx context.Context, tenantID, userID uuid.UUID) ([]application.UserRoleAssignment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []application.UserRoleAssignment
+	for roleID := range f.assignments[userID] {
+		result = append(result, application.UserRoleAssignment{UserID: userID, Role: f.roles[roleID]})
+	}
+	return result, nil
+}
+
+func (f *fakeGrantingRoleService) AssignUserToRole(ctx context.Context, tenantID, roleID, userID uuid.UUID, activatesAt time.Time, expiresAt *time.Time, assignedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.assignments[userID][roleID]; ok {
+		return application.ErrUserAlreadyAssigned
+	}
+	if f.assignments[userID] == nil {
+		f.assignments[userID] = make(map[uuid.UUID]*time.Time)
+	}
+	f.assignments[userID][roleID] = expiresAt
+	return nil
+}
+
+func (f *fakeGrantingRoleService) assigned(userID, roleID uuid.UUID) (*time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expiresAt, ok := f.assignments[userID][roleID]
+	return expiresAt, ok
+}
+
+// accessRequestFixture contém os identificadores do cenário de teste
+type accessRequestFixture struct {
+	repo                         *fakeAccessRequestRepository
+	roles                        *fakeGrantingRoleService
+	service                      application.AccessRequestService
+	tenantID                     uuid.UUID
+	finance, payroll, reportRead uuid.UUID
+	requester, owner, admin      uuid.UUID
+}
+
+// newAccessRequestFixture cria as funções Finance e Payroll, a permissão reports:read,
+// o dono da função Finance e um administrador delegado
+func newAccessRequestFixture(t *testing.T) *accessRequestFixture {
+	f := &accessRequestFixture{
+		repo:       newFakeAccessRequestRepository(),
+		tenantID:   uuid.New(),
+		finance:    uuid.New(),
+		payroll:    uuid.New(),
+		reportRead: uuid.New(),
+		requester:  uuid.New(),
+		owner:      uuid.New(),
+		admin:      uuid.New(),
+	}
+
+	f.roles = &fakeGrantingRoleService{
+		roles: map[uuid.UUID]*model.Role{
+			f.finance: {ID: f.finance, TenantID: f.tenantID, Code: "FINANCE", Name: "Finance", IsActive: true},
+			f.payroll: {ID: f.payroll, TenantID: f.tenantID, Code: "PAYROLL", Name: "Payroll", IsActive: true},
+		},
+		assignments: make(map[uuid.UUID]map[uuid.UUID]*time.Time),
+	}
+	f.repo.permissions[f.reportRead] = true
+	f.service = impl.NewAccessRequestService(f.repo, f.roles, 24*time.Hour)
+
+	ctx := context.Background()
+	_, err := f.service.AddApprover(ctx, &model.AccessRequestApprover{
+		TenantID: f.tenantID, UserID: f.owner, Kind: model.AccessApproverKindRoleOwner, RoleID: &f.finance,
+	})
+	require.NoError(t, err)
+	_, err = f.service.AddApprover(ctx, &model.AccessRequestApprover{
+		TenantID: f.tenantID, UserID: f.admin, Kind: model.AccessApproverKindDelegatedAdmin,
+	})
+	require.NoError(t, err)
+
+	return f
+}
+
+func (f *accessRequestFixture) submit(t *testing.T, targetType model.AccessRequestTarget, targetID uuid.UUID, expiresAt *time.Time) *model.AccessRequest {
+	req, err := f.service.Submit(context.Background(), &application.SubmitAccessRequest{
+		TenantID:      f.tenantID,
+		RequesterID:   f.requester,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		Justification: "Fecho do trimestre exige acesso aos relatórios",
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	return req
+}
+
+func TestAccessRequestService_SubmitValidation(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+
+	submit := func(targetType model.AccessRequestTarget, targetID uuid.UUID, justification string) error {
+		_, err := f.service.Submit(ctx, &application.SubmitAccessRequest{
+			TenantID:      f.tenantID,
+			RequesterID:   f.requester,
+			TargetType:    targetType,
+			TargetID:      targetID,
+			Justification: justification,
+		})
+		return err
+	}
+
+	assert.ErrorIs(t, submit(model.AccessRequestTargetRole, f.finance, "preciso"), model.ErrAccessRequestJustificationRequired)
+	assert.ErrorIs(t, submit("group", f.finance, "Fecho do trimestre"), model.ErrInvalidAccessRequest)
+	assert.ErrorIs(t, submit(model.AccessRequestTargetRole, uuid.New(), "Fecho do trimestre"), application.ErrRoleNotFound)
+	assert.ErrorIs(t, submit(model.AccessRequestTargetPermission, uuid.New(), "Fecho do trimestre"), application.ErrPermissionNotFound)
+
+	require.NoError(t, submit(model.AccessRequestTargetRole, f.finance, "Fecho do trimestre"))
+	assert.ErrorIs(t, submit(model.AccessRequestTargetRole, f.finance, "Fecho do trimestre"), model.ErrDuplicateAccessRequest)
+
+	// Quem já tem a função não a pode pedir
+	require.NoError(t, f.roles.AssignUserToRole(ctx, f.tenantID, f.payroll, f.requester, time.Now(), nil, f.admin))
+	assert.ErrorIs(t, submit(model.AccessRequestTargetRole, f.payroll, "Fecho do trimestre"), model.ErrAccessAlreadyGranted)
+}
+
+func TestAccessRequestService_ApproveRoleGrantsWithExpiry(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+
+	requested := time.Now().UTC().Add(30 * 24 * time.Hour)
+	req := f.submit(t, model.AccessRequestTargetRole, f.finance, &requested)
+
+	// O requerente não decide o próprio pedido, mesmo sendo aprovador
+	_, err := f.service.AddApprover(ctx, &model.AccessRequestApprover{
+		TenantID: f.tenantID, UserID: f.requester, Kind: model.AccessApproverKindDelegatedAdmin,
+	})
+	require.NoError(t, err)
+	_, err = f.service.Approve(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.requester})
+	assert.ErrorIs(t, err, model.ErrAccessRequestSelfApproval)
+
+	_, err = f.service.Approve(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: uuid.New()})
+	assert.ErrorIs(t, err, model.ErrAccessRequestApproverNotAuthorized)
+
+	// O aprovador encurta a validade pedida
+	granted := time.Now().UTC().Add(7 * 24 * time.Hour)
+	approved, err := f.service.Approve(ctx, &application.AccessRequestDecision{
+		TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.owner, Reason: "Aprovado até ao fecho", ExpiresAt: &granted,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.AccessRequestStatusApproved, approved.Status)
+	assert.Equal(t, f.owner, *approved.DecidedBy)
+	assert.Equal(t, model.AccessRequestStatusApproved, f.repo.status(req.ID))
+
+	expiresAt, ok := f.roles.assigned(f.requester, f.finance)
+	require.True(t, ok)
+	require.NotNil(t, expiresAt)
+	assert.True(t, expiresAt.Equal(granted))
+
+	_, err = f.service.Reject(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.admin, Reason: "tarde"})
+	assert.ErrorIs(t, err, model.ErrAccessRequestNotPending)
+}
+
+func TestAccessRequestService_PermissionRequestsNeedDelegatedAdmin(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+
+	req := f.submit(t, model.AccessRequestTargetPermission, f.reportRead, nil)
+
+	_, err := f.service.Approve(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.owner})
+	assert.ErrorIs(t, err, model.ErrAccessRequestApproverNotAuthorized)
+
+	// Uma falha na concessão repõe o pedido pendente
+	f.repo.grantErr = errors.New("base de dados indisponível")
+	_, err = f.service.Approve(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.admin})
+	require.Error(t, err)
+	assert.Equal(t, model.AccessRequestStatusPending, f.repo.status(req.ID))
+
+	f.repo.grantErr = nil
+	approved, err := f.service.Approve(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.admin})
+	require.NoError(t, err)
+	assert.Nil(t, approved.GrantExpiresAt)
+	assert.Contains(t, f.repo.grants, f.reportRead)
+}
+
+func TestAccessRequestService_RejectAndCancel(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+
+	req := f.submit(t, model.AccessRequestTargetRole, f.finance, nil)
+
+	_, err := f.service.Reject(ctx, &application.AccessRequestDecision{TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.owner})
+	assert.ErrorIs(t, err, model.ErrInvalidAccessRequest, "a rejeição exige motivo")
+
+	rejected, err := f.service.Reject(ctx, &application.AccessRequestDecision{
+		TenantID: f.tenantID, RequestID: req.ID, ApproverID: f.owner, Reason: "Sem necessidade funcional",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.AccessRequestStatusRejected, rejected.Status)
+	_, ok := f.roles.assigned(f.requester, f.finance)
+	assert.False(t, ok)
+
+	// Após a rejeição o requerente pode voltar a pedir, e só ele pode cancelar
+	again := f.submit(t, model.AccessRequestTargetRole, f.finance, nil)
+	_, err = f.service.Cancel(ctx, f.tenantID, again.ID, f.owner)
+	assert.ErrorIs(t, err, model.ErrAccessRequestNotRequester)
+
+	cancelled, err := f.service.Cancel(ctx, f.tenantID, again.ID, f.requester)
+	require.NoError(t, err)
+	assert.Equal(t, model.AccessRequestStatusCancelled, cancelled.Status)
+}
+
+func TestAccessRequestService_ListPendingForApprover(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+
+	financeReq := f.submit(t, model.AccessRequestTargetRole, f.finance, nil)
+	payrollReq := f.submit(t, model.AccessRequestTargetRole, f.payroll, nil)
+	permissionReq := f.submit(t, model.AccessRequestTargetPermission, f.reportRead, nil)
+
+	ids := func(requests []*model.AccessRequest) []uuid.UUID {
+		var result []uuid.UUID
+		for _, req := range requests {
+			result = append(result, req.ID)
+		}
+		return result
+	}
+
+	ownerInbox, err := f.service.ListPendingForApprover(ctx, f.tenantID, f.owner)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{financeReq.ID}, ids(ownerInbox))
+
+	adminInbox, err := f.service.ListPendingForApprover(ctx, f.tenantID, f.admin)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{financeReq.ID, payrollReq.ID, permissionReq.ID}, ids(adminInbox))
+
+	strangerInbox, err := f.service.ListPendingForApprover(ctx, f.tenantID, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, strangerInbox)
+}
+
+func TestAccessRequestService_SLAMetrics(t *testing.T) {
+	ctx := context.Background()
+	f := newAccessRequestFixture(t)
+	now := time.Now().UTC()
+
+	seed := func(status model.AccessRequestStatus, createdAgo, decidedAgo time.Duration) {
+		req := &model.AccessRequest{
+			ID: uuid.New(), TenantID: f.tenantID, RequesterID: uuid.New(),
+			TargetType: model.AccessRequestTargetRole, TargetID: f.finance,
+			Justification: "Pedido semeado", Status: status, CreatedAt: now.Add(-createdAgo),
+		}
+		if status != model.AccessRequestStatusPending {
+			decidedAt := now.Add(-decidedAgo)
+			req.DecidedAt = &decidedAt
+		}
+		f.repo.requests[req.ID] = req
+	}
+
+	seed(model.AccessRequestStatusPending, 2*time.Hour, 0)
+	seed(model.AccessRequestStatusPending, 50*time.Hour, 0)
+	seed(model.AccessRequestStatusApproved, 10*time.Hour, 6*time.Hour)        // decidido em 4h
+	seed(model.AccessRequestStatusRejected, 80*time.Hour, 40*time.Hour)       // decidido em 40h
+	seed(model.AccessRequestStatusCancelled, 5*time.Hour, 4*time.Hour)        // não conta
+	seed(model.AccessRequestStatusApproved, 90*24*time.Hour, 60*24*time.Hour) // fora da janela
+
+	metrics, err := f.service.GetSLAMetrics(ctx, f.tenantID)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(24*3600), metrics.SLASeconds)
+	assert.Equal(t, 2, metrics.PendingCount)
+	assert.Equal(t, 1, metrics.PendingOverSLA)
+	assert.InDelta(t, 50*3600, metrics.OldestPendingSeconds, 60)
+	assert.Equal(t, 2, metrics.DecidedCount)
+	assert.Equal(t, 1, metrics.DecidedWithinSLA)
+	assert.Equal(t, int64(22*3600), metrics.AverageDecisionTimeSeconds)
+	assert.Equal(t, int64(40*3600), metrics.MaxDecisionTimeSeconds)
+}
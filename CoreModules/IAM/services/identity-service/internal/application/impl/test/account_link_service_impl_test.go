@@ -0,0 +1,508 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a ligação de contas (AccountLinkService).
+ * Valida a deteção dos candidatos pelo email verificado, a exigência de autenticação recente,
+ * a proteção contra a remoção do último método de login e o registo de auditoria.
+ */
+
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeAccountLinkRepository é um AccountLinkRepository em memória
+type fakeAccountLinkRepository struct {
+	mu         sync.Mutex
+	users      map[uuid.UUID]*model.User
+	passwords  map[uuid.UUID]bool
+	identities map[uuid.UUID]*model.FederatedIdentity
+	candidates map[uuid.UUID]*model.AccountLinkCandidate
+	events     []*model.AccountLinkEvent
+	lastFilter model.AccountLinkEventFilter
+}
+
+func newFakeAccountLinkRepository() *fakeAccountLinkRepository {
+	return &fakeAccountLinkRepository{
+		users:      make(map[uuid.UUID]*model.User),
+		passwords:  make(map[uuid.UUID]bool),
+		identities: make(map[uuid.UUID]*model.FederatedIdentity),
+		candidates: make(map[uuid.UUID]*model.AccountLinkCandidate),
+	}
+}
+
+func (r *fakeAccountLinkRepository) FindUserByVerifiedEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.TenantID == tenantID && user.EmailVerified && strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, model.ErrUserNotFound
+}
+
+func (r *fakeAccountLinkRepository) ListFederatedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.FederatedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var identities []*model.FederatedIdentity
+	for _, identity := range r.identities {
+		if identity.TenantID == tenantID && identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeAccountLinkRepository) ListLinkedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LinkedIdentity, error) {
+	federated, _ := r.ListFederatedIdentities(ctx, tenantID, userID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var identities []*model.LinkedIdentity
+	if r.passwords[userID] {
+		identities = append(identities, &model.LinkedIdentity{ID: userID, Kind: model.LinkedIdentityPassword})
+	}
+	for _, identity := range federated {
+		providerID := identity.ProviderID
+		identities = append(identities, &model.LinkedIdentity{
+			ID: identity.ID, Kind: model.LinkedIdentitySAML, ProviderID: &providerID, Subject: identity.Subject,
+		})
+	}
+	return identities, nil
+}
+
+func (r *fakeAccountLinkRepository) SaveCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.candidates {
+		if existing.ProviderID == candidate.ProviderID && existing.Subject == candidate.Subject &&
+			existing.Status == model.AccountLinkCandidatePending {
+			existing.Status = model.AccountLinkCandidateExpired
+		}
+	}
+	copied := *candidate
+	r.candidates[candidate.ID] = &copied
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeAccountLinkRepository) GetCandidate(ctx context.Context, tenantID, candidateID uuid.UUID) (*model.AccountLinkCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate, ok := r.candidates[candidateID]
+	if !ok || candidate.TenantID != tenantID {
+		return nil, model.ErrAccountLinkCandidateNotFound
+	}
+	copied := *candidate
+	return &copied, nil
+}
+
+func (r *fakeAccountLinkRepository) ListOpenCandidates(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.AccountLinkCandidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates []*model.AccountLinkCandidate
+	for _, candidate := range r.candidates {
+		if candidate.TenantID == tenantID && candidate.UserID == userID && candidate.IsOpen(now) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func (r *fakeAccountLinkRepository) LinkCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, identity *model.FederatedIdentity, event *model.AccountLinkEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.resolve(candidate); err != nil {
+		return err
+	}
+	for _, existing := range r.identities {
+		if existing.ProviderID == identity.ProviderID && existing.Subject == identity.Subject {
+			return model.ErrIdentityAlreadyLinked
+		}
+	}
+	r.identities[identity.ID] = identity
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeAccountLinkRepository) DismissCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.resolve(candidate); err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeAccountLinkRepository) UnlinkIdentity(ctx context.Context, tenantID, userID, identityID uuid.UUID, event *model.AccountLinkEvent) (*model.FederatedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[identityID]
+	if !ok || identity.TenantID != tenantID || identity.UserID != userID {
+		return nil, model.ErrLinkedIdentityNotFound
+	}
+	remaining := 0
+	for _, other := range r.identities {
+		if other.UserID == userID && other.ID != identityID {
+			remaining++
+		}
+	}
+	if !r.passwords[userID] && remaining == 0 {
+		return nil, model.ErrLastLinkedIdentity
+	}
+
+	delete(r.identities, identityID)
+	event.ProviderID = identity.ProviderID
+	event.Subject = identity.Subject
+	r.events = append(r.events, event)
+	return identity, nil
+}
+
+func (r *fakeAccountLinkRepository) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.AccountLinkEventFilter) ([]*model.AccountLinkEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastFilter = filter
+	var events []*model.AccountLinkEvent
+	for _, event := range r.events {
+		if event.TenantID != tenantID || (filter.Action != "" && event.Action != filter.Action) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// resolve grava o estado final de um candidato ainda pendente
+func (r *fakeAccountLinkRepository) resolve(candidate *model.AccountLinkCandidate) error {
+	stored, ok := r.candidates[candidate.ID]
+	if !ok || stored.Status != model.AccountLinkCandidatePending {
+		return model.ErrAccountLinkCandidateClosed
+	}
+	stored.Status = candidate.Status
+	stored.ResolvedAt = candidate.ResolvedAt
+	return nil
+}
+
+// addUser cria uma conta local com senha
+func (r *fakeAccountLinkRepository) addUser(tenantID uuid.UUID, email string, verified bool) *model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := &model.User{
+		ID: uuid.New(), TenantID: tenantID, Username: email, Email: email, EmailVerified: verified,
+		Status: model.UserStatusActive,
+	}
+	r.users[user.ID] = user
+	r.passwords[user.ID] = true
+	return user
+}
+
+func (r *fakeAccountLinkRepository) actions() []model.AccountLinkAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actions := make([]model.AccountLinkAction, 0, len(r.events))
+	for _, event := range r.events {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+// accountLinkFixture contém um tenant com uma conta local verificada e um fornecedor SAML
+type accountLinkFixture struct {
+	repo       *fakeAccountLinkRepository
+	roles      *fakeSAMLRoleService
+	service    application.AccountLinkService
+	tenantID   uuid.UUID
+	providerID uuid.UUID
+	user       *model.User
+}
+
+func newAccountLinkFixture() *accountLinkFixture {
+	f := &accountLinkFixture{
+		repo:       newFakeAccountLinkRepository(),
+		roles:      &fakeSAMLRoleService{assignments: make(map[uuid.UUID]map[uuid.UUID]bool)},
+		tenantID:   uuid.New(),
+		providerID: uuid.New(),
+	}
+	f.user = f.repo.addUser(f.tenantID, "ana@acme.co.ao", true)
+	f.service = impl.NewAccountLinkService(f.repo, f.roles, impl.DefaultAccountLinkConfig())
+	return f
+}
+
+// detect simula um login federado com um NameID desconhecido e o email da conta local
+func (f *accountLinkFixture) detect(t *testing.T, subject string) *model.AccountLinkCandidate {
+	candidate, err := f.service.DetectCandidate(context.Background(), &application.DetectAccountLinkRequest{
+		TenantID: f.tenantID, ProviderID: f.providerID, Subject: subject, Email: "Ana@Acme.co.ao", IPAddress: "10.0.0.1",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, candidate)
+	return candidate
+}
+
+func (f *accountLinkFixture) link(candidateID uuid.UUID, authenticatedAt time.Time) (*model.LinkedIdentity, error) {
+	return f.service.Link(context.Background(), &application.LinkAccountRequest{
+		TenantID: f.tenantID, UserID: f.user.ID, CandidateID: candidateID, AuthenticatedAt: authenticatedAt,
+	})
+}
+
+func TestAccountLinkService_DetectCandidate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("email verificado gera candidato pendente", func(t *testing.T) {
+		f := newAccountLinkFixture()
+
+		candidate := f.detect(t, "ana.silva")
+		assert.Equal(t, f.user.ID, candidate.UserID)
+		assert.Equal(t, "ana@acme.co.ao", candidate.Email)
+		assert.Equal(t, model.AccountLinkCandidatePending, candidate.Status)
+		assert.Equal(t, impl.DefaultAccountLinkCandidateTTL, candidate.ExpiresAt.Sub(candidate.DetectedAt))
+		assert.Equal(t, []model.AccountLinkAction{model.AccountLinkActionCandidateDetected}, f.repo.actions())
+
+		again := f.detect(t, "ana.silva")
+		assert.Equal(t, model.AccountLinkCandidateExpired, f.repo.candidates[candidate.ID].Status,
+			"um novo login substitui o candidato pendente anterior")
+		assert.Equal(t, model.AccountLinkCandidatePending, f.repo.candidates[again.ID].Status)
+	})
+
+	t.Run("sem conta com o email verificado", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		f.repo.addUser(f.tenantID, "rui@acme.co.ao", false)
+
+		for _, email := range []string{"rui@acme.co.ao", "desconhecido@acme.co.ao", ""} {
+			candidate, err := f.service.DetectCandidate(ctx, &application.DetectAccountLinkRequest{
+				TenantID: f.tenantID, ProviderID: f.providerID, Subject: "rui", Email: email,
+			})
+			require.NoError(t, err)
+			assert.Nil(t, candidate, email)
+		}
+		assert.Empty(t, f.repo.events)
+	})
+
+	t.Run("conta suspensa", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		f.user.Status = model.UserStatusSuspended
+
+		candidate, err := f.service.DetectCandidate(ctx, &application.DetectAccountLinkRequest{
+			TenantID: f.tenantID, ProviderID: f.providerID, Subject: "ana", Email: f.user.Email,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, candidate)
+	})
+
+	t.Run("usuário já ligado ao fornecedor com outro NameID", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		identity := &model.FederatedIdentity{
+			ID: uuid.New(), TenantID: f.tenantID, ProviderID: f.providerID, Subject: "ana-antiga", UserID: f.user.ID,
+		}
+		f.repo.identities[identity.ID] = identity
+
+		candidate, err := f.service.DetectCandidate(ctx, &application.DetectAccountLinkRequest{
+			TenantID: f.tenantID, ProviderID: f.providerID, Subject: "ana-nova", Email: f.user.Email,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, candidate)
+	})
+}
+
+func TestAccountLinkService_Link(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("autenticação recente liga a identidade", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		candidate := f.detect(t, "ana.silva")
+
+		candidates, err := f.service.ListCandidates(ctx, f.tenantID, f.user.ID)
+		require.NoError(t, err)
+		assert.Len(t, candidates, 1)
+
+		authenticatedAt := time.Now().Add(-time.Minute)
+		identity, err := f.link(candidate.ID, authenticatedAt)
+		require.NoError(t, err)
+		assert.Equal(t, model.LinkedIdentitySAML, identity.Kind)
+		assert.Equal(t, f.providerID, *identity.ProviderID)
+		assert.Equal(t, "ana.silva", identity.Subject)
+		assert.Equal(t, model.AccountLinkCandidateLinked, f.repo.candidates[candidate.ID].Status)
+
+		linked, err := f.service.ListLinkedIdentities(ctx, f.tenantID, f.user.ID)
+		require.NoError(t, err)
+		require.Len(t, linked, 2)
+		assert.Equal(t, model.LinkedIdentityPassword, linked[0].Kind)
+
+		event := f.repo.events[len(f.repo.events)-1]
+		assert.Equal(t, model.AccountLinkActionLinked, event.Action)
+		assert.Equal(t, f.user.ID, *event.ActorID)
+		assert.Equal(t, identity.ID, *event.IdentityID)
+		assert.Equal(t, candidate.ID, *event.CandidateID)
+		assert.WithinDuration(t, authenticatedAt, *event.AuthenticatedAt, time.Second)
+
+		candidates, err = f.service.ListCandidates(ctx, f.tenantID, f.user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, candidates)
+
+		_, err = f.link(candidate.ID, time.Now())
+		assert.ErrorIs(t, err, application.ErrAccountLinkCandidateClosed)
+	})
+
+	t.Run("autenticação antiga ou ausente", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		candidate := f.detect(t, "ana.silva")
+
+		for _, authenticatedAt := range []time.Time{{}, time.Now().Add(-time.Hour), time.Now().Add(time.Hour)} {
+			_, err := f.link(candidate.ID, authenticatedAt)
+			assert.ErrorIs(t, err, application.ErrReauthenticationRequired)
+		}
+		assert.Equal(t, model.AccountLinkCandidatePending, f.repo.candidates[candidate.ID].Status)
+	})
+
+	t.Run("candidato de outro usuário não é revelado", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		candidate := f.detect(t, "ana.silva")
+		other := f.repo.addUser(f.tenantID, "rui@acme.co.ao", true)
+
+		_, err := f.service.Link(ctx, &application.LinkAccountRequest{
+			TenantID: f.tenantID, UserID: other.ID, CandidateID: candidate.ID, AuthenticatedAt: time.Now(),
+		})
+		assert.ErrorIs(t, err, application.ErrAccountLinkCandidateNotFound)
+
+		_, err = f.service.DismissCandidate(ctx, f.tenantID, other.ID, candidate.ID, "")
+		assert.ErrorIs(t, err, application.ErrAccountLinkCandidateNotFound)
+	})
+
+	t.Run("candidato expirado", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		candidate := f.detect(t, "ana.silva")
+		f.repo.candidates[candidate.ID].ExpiresAt = time.Now().Add(-time.Second)
+
+		_, err := f.link(candidate.ID, time.Now())
+		assert.ErrorIs(t, err, application.ErrAccountLinkCandidateClosed)
+	})
+
+	t.Run("candidato recusado", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		candidate := f.detect(t, "ana.silva")
+
+		dismissed, err := f.service.DismissCandidate(ctx, f.tenantID, f.user.ID, candidate.ID, "10.0.0.2")
+		require.NoError(t, err)
+		assert.Equal(t, model.AccountLinkCandidateDismissed, dismissed.Status)
+		assert.NotNil(t, dismissed.ResolvedAt)
+
+		_, err = f.link(candidate.ID, time.Now())
+		assert.ErrorIs(t, err, application.ErrAccountLinkCandidateClosed)
+		assert.Equal(t, []model.AccountLinkAction{
+			model.AccountLinkActionCandidateDetected,
+			model.AccountLinkActionCandidateDismissed,
+		}, f.repo.actions())
+	})
+}
+
+func TestAccountLinkService_Unlink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retira as funções atribuídas pela federação", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		roleID := uuid.New()
+		identity := &model.FederatedIdentity{
+			ID: uuid.New(), TenantID: f.tenantID, ProviderID: f.providerID, Subject: "ana", UserID: f.user.ID,
+			GrantedRoleIDs: []uuid.UUID{roleID, uuid.New()},
+		}
+		f.repo.identities[identity.ID] = identity
+		f.roles.assignments[f.user.ID] = map[uuid.UUID]bool{roleID: true}
+
+		err := f.service.Unlink(ctx, &application.UnlinkAccountRequest{
+			TenantID: f.tenantID, UserID: f.user.ID, IdentityID: identity.ID, AuthenticatedAt: time.Now(), IPAddress: "10.0.0.3",
+		})
+		require.NoError(t, err)
+		assert.False(t, f.roles.hasRole(f.user.ID, roleID))
+		assert.NotContains(t, f.repo.identities, identity.ID)
+
+		event := f.repo.events[len(f.repo.events)-1]
+		assert.Equal(t, model.AccountLinkActionUnlinked, event.Action)
+		assert.Equal(t, f.providerID, event.ProviderID)
+		assert.Equal(t, "ana", event.Subject)
+		assert.Equal(t, "10.0.0.3", event.IPAddress)
+		assert.NotNil(t, event.AuthenticatedAt)
+	})
+
+	t.Run("último método de login", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		f.repo.passwords[f.user.ID] = false
+		identity := &model.FederatedIdentity{
+			ID: uuid.New(), TenantID: f.tenantID, ProviderID: f.providerID, Subject: "ana", UserID: f.user.ID,
+		}
+		f.repo.identities[identity.ID] = identity
+
+		err := f.service.Unlink(ctx, &application.UnlinkAccountRequest{
+			TenantID: f.tenantID, UserID: f.user.ID, IdentityID: identity.ID, AuthenticatedAt: time.Now(),
+		})
+		assert.ErrorIs(t, err, application.ErrLastLinkedIdentity)
+		assert.Contains(t, f.repo.identities, identity.ID)
+	})
+
+	t.Run("identidade de outro usuário ou autenticação antiga", func(t *testing.T) {
+		f := newAccountLinkFixture()
+		identity := &model.FederatedIdentity{
+			ID: uuid.New(), TenantID: f.tenantID, ProviderID: f.providerID, Subject: "rui", UserID: uuid.New(),
+		}
+		f.repo.identities[identity.ID] = identity
+
+		err := f.service.Unlink(ctx, &application.UnlinkAccountRequest{
+			TenantID: f.tenantID, UserID: f.user.ID, IdentityID: identity.ID, AuthenticatedAt: time.Now(),
+		})
+		assert.ErrorIs(t, err, application.ErrLinkedIdentityNotFound)
+
+		err = f.service.Unlink(ctx, &application.UnlinkAccountRequest{
+			TenantID: f.tenantID, UserID: identity.UserID, IdentityID: identity.ID, AuthenticatedAt: time.Now().Add(-time.Hour),
+		})
+		assert.ErrorIs(t, err, application.ErrReauthenticationRequired)
+		assert.Empty(t, f.repo.events)
+	})
+}
+
+func TestAccountLinkService_ListEvents(t *testing.T) {
+	ctx := context.Background()
+	f := newAccountLinkFixture()
+	f.detect(t, "ana.silva")
+
+	events, err := f.service.ListEvents(ctx, f.tenantID, model.AccountLinkEventFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, impl.DefaultAccountLinkEventsLimit, f.repo.lastFilter.Limit)
+
+	_, err = f.service.ListEvents(ctx, f.tenantID, model.AccountLinkEventFilter{Limit: 5000})
+	require.NoError(t, err)
+	assert.Equal(t, impl.MaxAccountLinkEventsLimit, f.repo.lastFilter.Limit)
+
+	_, err = f.service.ListEvents(ctx, f.tenantID, model.AccountLinkEventFilter{Action: "apagado"})
+	assert.ErrorIs(t, err, application.ErrInvalidAccountLinkFilter)
+
+	events, err = f.service.ListEvents(ctx, uuid.New(), model.AccountLinkEventFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,317 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a deteção de anomalias no fluxo de auditoria (AuditAnomalyService).
+ * Valida as regras de deteção, o mapeamento de severidades, a publicação dos eventos de
+ * segurança e a triagem dos incidentes.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeSecurityIncidentRepository é um SecurityIncidentRepository em memória
+type fakeSecurityIncidentRepository struct {
+	mu        sync.Mutex
+	incidents map[uuid.UUID]*model.SecurityIncident
+	createErr error
+}
+
+func newFakeSecurityIncidentRepository() *fakeSecurityIncidentRepository {
+	return &fakeSecurityIncidentRepository{incidents: make(map[uuid.UUID]*model.SecurityIncident)}
+}
+
+func (r *fakeSecurityIncidentRepository) Create(ctx context.Context, incident *model.SecurityIncident) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.createErr != nil {
+		return r.createErr
+	}
+	copied := *incident
+	r.incidents[incident.ID] = &copied
+	return nil
+}
+
+func (r *fakeSecurityIncidentRepository) Get(ctx context.Context, tenantID, incidentID uuid.UUID) (*model.SecurityIncident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	incident, ok := r.incidents[incidentID]
+	if !ok || incident.TenantID != tenantID {
+		return nil, model.ErrSecurityIncidentNotFound
+	}
+	copied := *incident
+	return &copied, nil
+}
+
+func (r *fakeSecurityIncidentRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.SecurityIncidentFilter) ([]*model.SecurityIncident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var incidents []*model.SecurityIncident
+	for _, incident := range r.incidents {
+		if incident.TenantID != tenantID {
+			continue
+		}
+		if filter.Severity != "" && incident.Severity != filter.Severity {
+			continue
+		}
+		copied := *incident
+		incidents = append(incidents, &copied)
+	}
+	return incidents, nil
+}
+
+func (r *fakeSecurityIncidentRepository) SaveStatus(ctx context.Context, incident *model.SecurityIncident, expected model.SecurityIncidentStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.incidents[incident.ID]
+	if !ok || stored.Status != expected {
+		return model.ErrSecurityIncidentConflict
+	}
+	copied := *incident
+	r.incidents[incident.ID] = &copied
+	return nil
+}
+
+// Segunda-feira, dentro e fora do horário de expediente padrão
+var (
+	anomalyBusinessHours = time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	anomalyOffHours      = time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC)
+)
+
+func newTestAuditAnomalyService() (application.AuditAnomalyService, *fakeSecurityIncidentRepository, *recordingPublisher) {
+	repo := newFakeSecurityIncidentRepository()
+	publisher := &recordingPublisher{}
+	return impl.NewAuditAnomalyService(repo, publisher, impl.DefaultAuditAnomalyConfig()), repo, publisher
+}
+
+func roleAssignment(tenantID uuid.UUID, roleCode string, assignedBy *uuid.UUID, at time.Time, userIDs ...uuid.UUID) *event.RoleAssignedToUsersEvent {
+	return &event.RoleAssignedToUsersEvent{
+		TenantID:   tenantID,
+		RoleID:     uuid.New(),
+		RoleCode:   roleCode,
+		UserIDs:    userIDs,
+		AssignedAt: at,
+		AssignedBy: assignedBy,
+		EventTime:  at,
+	}
+}
+
+func TestAuditAnomaly_OffHoursPrivilegeGrant(t *testing.T) {
+	service, _, publisher := newTestAuditAnomalyService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	actorID := uuid.New()
+
+	incidents, err := service.Analyze(ctx, roleAssignment(tenantID, "AUDITOR", &actorID, anomalyBusinessHours, uuid.New()))
+	require.NoError(t, err)
+	assert.Empty(t, incidents)
+
+	incidents, err = service.Analyze(ctx, roleAssignment(tenantID, "AUDITOR", &actorID, anomalyOffHours, uuid.New()))
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, model.SecurityIncidentRuleOffHoursPrivilegeGrant, incidents[0].Rule)
+	assert.Equal(t, model.SecurityIncidentSeverityMedium, incidents[0].Severity)
+	assert.Equal(t, model.SecurityIncidentStatusOpen, incidents[0].Status)
+	assert.Equal(t, &actorID, incidents[0].ActorID)
+
+	// A atribuição de uma função administrativa fora de horas é mais grave
+	incidents, err = service.Analyze(ctx, roleAssignment(tenantID, "tenant_admin", &actorID, anomalyOffHours, uuid.New()))
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, model.SecurityIncidentSeverityHigh, incidents[0].Severity)
+
+	published := publisher.published()
+	require.Len(t, published, 2)
+	anomaly, ok := published[1].(*event.SecurityAnomalyDetectedEvent)
+	require.True(t, ok)
+	assert.Equal(t, event.TopicSecurityAnomalyDetected, anomaly.GetType())
+	assert.Equal(t, incidents[0].ID, anomaly.IncidentID)
+	assert.Equal(t, model.SecurityIncidentSeverityHigh, anomaly.Severity)
+}
+
+func TestAuditAnomaly_PermissionGrantByNewAdmin(t *testing.T) {
+	service, _, _ := newTestAuditAnomalyService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	newAdminID := uuid.New()
+
+	_, err := service.Analyze(ctx, roleAssignment(tenantID, "ADMIN", nil, anomalyBusinessHours, newAdminID))
+	require.NoError(t, err)
+
+	grant := &event.PermissionsAssignedToRoleEvent{
+		TenantID:      tenantID,
+		RoleID:        uuid.New(),
+		RoleCode:      "SUPER_ADMIN",
+		PermissionIDs: []uuid.UUID{uuid.New(), uuid.New()},
+		AssignedBy:    &newAdminID,
+		EventTime:     anomalyBusinessHours.Add(2 * time.Hour),
+	}
+	incidents, err := service.Analyze(ctx, grant)
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, model.SecurityIncidentRuleNewAdminPermissionGrant, incidents[0].Rule)
+	assert.Equal(t, model.SecurityIncidentSeverityCritical, incidents[0].Severity)
+	require.Len(t, incidents[0].Evidence, 1)
+	assert.Equal(t, grant.PermissionIDs, incidents[0].Evidence[0].SubjectIDs)
+
+	// Noutro tenant o mesmo usuário não é administrador
+	grant.TenantID = uuid.New()
+	incidents, err = service.Analyze(ctx, grant)
+	require.NoError(t, err)
+	assert.Empty(t, incidents)
+
+	// Passada a janela de observação o administrador deixa de ser recente
+	grant.TenantID = tenantID
+	grant.EventTime = anomalyBusinessHours.Add(impl.DefaultNewAdminObservationWindow + time.Hour)
+	incidents, err = service.Analyze(ctx, grant)
+	require.NoError(t, err)
+	assert.Empty(t, incidents)
+}
+
+func TestAuditAnomaly_MassRoleDeletion(t *testing.T) {
+	service, repo, _ := newTestAuditAnomalyService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	actorID := uuid.New()
+	otherActorID := uuid.New()
+
+	deleteRole := func(actor uuid.UUID, at time.Time) []*model.SecurityIncident {
+		incidents, err := service.Analyze(ctx, &event.RoleSoftDeletedEvent{
+			TenantID:  tenantID,
+			RoleID:    uuid.New(),
+			Code:      "ROLE",
+			DeletedBy: &actor,
+			EventTime: at,
+		})
+		require.NoError(t, err)
+		return incidents
+	}
+
+	var opened []*model.SecurityIncident
+	for i := 0; i < 2*impl.DefaultMassRoleDeletionCount; i++ {
+		at := anomalyBusinessHours.Add(time.Duration(i) * 10 * time.Second)
+		if i < impl.DefaultMassRoleDeletionCount-1 {
+			assert.Empty(t, deleteRole(otherActorID, at), "as exclusões de outro ator contam à parte")
+		}
+		opened = append(opened, deleteRole(actorID, at)...)
+		if i == impl.DefaultMassRoleDeletionCount-2 {
+			assert.Empty(t, opened, "abaixo do limiar não há incidente")
+		}
+	}
+
+	require.Len(t, opened, 2, "um incidente ao atingir o limiar e outro na escalada")
+	assert.Len(t, repo.incidents, 2)
+
+	severities := map[model.SecurityIncidentSeverity]int{}
+	for _, incident := range opened {
+		assert.Equal(t, &actorID, incident.ActorID)
+		assert.Equal(t, model.SecurityIncidentRuleMassRoleDeletion, incident.Rule)
+		severities[incident.Severity] = len(incident.Evidence)
+	}
+	assert.Equal(t, impl.DefaultMassRoleDeletionCount, severities[model.SecurityIncidentSeverityHigh])
+	assert.Equal(t, 2*impl.DefaultMassRoleDeletionCount, severities[model.SecurityIncidentSeverityCritical])
+
+	// Fora da janela as exclusões antigas deixam de contar
+	later := anomalyBusinessHours.Add(impl.DefaultMassRoleDeletionWindow + time.Hour)
+	assert.Empty(t, deleteRole(actorID, later))
+}
+
+func TestAuditAnomaly_IgnoresUnrelatedEvents(t *testing.T) {
+	service, repo, publisher := newTestAuditAnomalyService()
+
+	incidents, err := service.Analyze(context.Background(), &event.RoleCreatedEvent{
+		TenantID:  uuid.New(),
+		RoleID:    uuid.New(),
+		Code:      "ADMIN",
+		EventTime: anomalyOffHours,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, incidents)
+	assert.Empty(t, repo.incidents)
+	assert.Empty(t, publisher.published())
+}
+
+func TestAuditAnomaly_Triage(t *testing.T) {
+	service, _, _ := newTestAuditAnomalyService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	analystID := uuid.New()
+
+	incidents, err := service.Analyze(ctx, roleAssignment(tenantID, "ADMIN", nil, anomalyOffHours, uuid.New()))
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	incidentID := incidents[0].ID
+
+	triage := &application.SecurityIncidentTriage{TenantID: tenantID, IncidentID: incidentID, ActorID: analystID}
+	incident, err := service.Acknowledge(ctx, triage)
+	require.NoError(t, err)
+	assert.Equal(t, model.SecurityIncidentStatusAcknowledged, incident.Status)
+	assert.Equal(t, &analystID, incident.TriagedBy)
+	assert.Nil(t, incident.ClosedAt)
+
+	_, err = service.Acknowledge(ctx, triage)
+	assert.True(t, errors.Is(err, application.ErrInvalidSecurityIncidentTransition))
+
+	_, err = service.Resolve(ctx, triage)
+	assert.True(t, errors.Is(err, application.ErrInvalidSecurityIncident), "a nota é obrigatória para fechar")
+
+	triage.Note = "Atribuição confirmada com o dono da função"
+	incident, err = service.Resolve(ctx, triage)
+	require.NoError(t, err)
+	assert.Equal(t, model.SecurityIncidentStatusResolved, incident.Status)
+	assert.Equal(t, triage.Note, incident.ResolutionNote)
+	require.NotNil(t, incident.ClosedAt)
+
+	_, err = service.Dismiss(ctx, triage)
+	assert.True(t, errors.Is(err, application.ErrInvalidSecurityIncidentTransition))
+
+	stored, err := service.GetIncident(ctx, tenantID, incidentID)
+	require.NoError(t, err)
+	assert.Equal(t, model.SecurityIncidentStatusResolved, stored.Status)
+
+	_, err = service.GetIncident(ctx, uuid.New(), incidentID)
+	assert.True(t, errors.Is(err, application.ErrSecurityIncidentNotFound))
+}
+
+func TestAuditAnomaly_ListIncidentsRejectsUnknownSeverity(t *testing.T) {
+	service, _, _ := newTestAuditAnomalyService()
+
+	_, err := service.ListIncidents(context.Background(), uuid.New(), model.SecurityIncidentFilter{Severity: "urgent"})
+	assert.True(t, errors.Is(err, application.ErrInvalidSecurityIncident))
+}
+
+func TestAnomalyDetectingPublisher_ForwardsAndAnalyzes(t *testing.T) {
+	service, repo, _ := newTestAuditAnomalyService()
+	next := &recordingPublisher{}
+	publisher := impl.NewAnomalyDetectingPublisher(next, service)
+
+	evt := roleAssignment(uuid.New(), "ADMIN", nil, anomalyOffHours, uuid.New())
+	require.NoError(t, publisher.Publish(context.Background(), evt))
+	assert.Equal(t, []event.Event{evt}, next.published())
+	assert.Len(t, repo.incidents, 1)
+
+	// Uma falha na análise não impede a publicação
+	repo.createErr = errors.New("base de dados indisponível")
+	require.NoError(t, publisher.Publish(context.Background(), evt))
+	assert.Len(t, next.published(), 2)
+}
@@ -0,0 +1,244 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a consulta dos registos de auditoria (AuditLogService).
+ * Valida os filtros, a paginação por cursor sem repetições nem falhas e a exportação CSV.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeAuditLogRepository é um AuditLogRepository em memória
+// A pesquisa em texto livre é aproximada por uma procura nos detalhes
+type fakeAuditLogRepository struct {
+	entries []*model.AuditLogEntry
+	limits  []int
+}
+
+func (r *fakeAuditLogRepository) Search(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) ([]*model.AuditLogEntry, error) {
+	r.limits = append(r.limits, filter.Limit)
+
+	var result []*model.AuditLogEntry
+	for _, entry := range r.entries {
+		if entry.TenantID != tenantID ||
+			(filter.Query != "" && !strings.Contains(entry.Details, filter.Query)) ||
+			(filter.ActorID != nil && (entry.UserID == nil || *entry.UserID != *filter.ActorID)) ||
+			(filter.TargetType != "" && entry.EntityType != filter.TargetType) ||
+			(filter.Market != "" && entry.Market != filter.Market) ||
+			(filter.From != nil && entry.CreatedAt.Before(*filter.From)) ||
+			(filter.To != nil && !entry.CreatedAt.Before(*filter.To)) {
+			continue
+		}
+		if len(filter.Severities) > 0 && !containsSeverity(filter.Severities, entry.Severity) {
+			continue
+		}
+		if filter.Cursor != nil && !auditLogBefore(entry, filter.Cursor) {
+			continue
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return auditLogBefore(result[j], &model.AuditLogCursor{CreatedAt: result[i].CreatedAt, ID: result[i].ID})
+	})
+	if len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+// auditLogBefore indica se o registo vem depois do cursor na ordem (created_at, id) decrescente
+func auditLogBefore(entry *model.AuditLogEntry, cursor *model.AuditLogCursor) bool {
+	if !entry.CreatedAt.Equal(cursor.CreatedAt) {
+		return entry.CreatedAt.Before(cursor.CreatedAt)
+	}
+	return strings.Compare(entry.ID.String(), cursor.ID.String()) < 0
+}
+
+func containsSeverity(severities []model.AuditSeverity, severity model.AuditSeverity) bool {
+	for _, s := range severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// newAuditLogFixture cria registos do tenant, vários no mesmo instante, alternando mercado e severidade
+func newAuditLogFixture(tenantID, actorID uuid.UUID, count int) *fakeAuditLogRepository {
+	base := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeAuditLogRepository{}
+	for i := 0; i < count; i++ {
+		entry := &model.AuditLogEntry{
+			ID:         uuid.New(),
+			TenantID:   tenantID,
+			EventType:  "ROLE_UPDATED",
+			EntityType: "role",
+			EntityID:   uuid.New(),
+			Severity:   model.AuditSeverityInfo,
+			Market:     "AO",
+			Details:    fmt.Sprintf("alteração %d do papel", i),
+			CreatedAt:  base.Add(time.Duration(i/3) * time.Minute),
+		}
+		if i%2 == 0 {
+			entry.UserID = &actorID
+			entry.Market = "MZ"
+			entry.Severity = model.AuditSeverityHigh
+		}
+		repo.entries = append(repo.entries, entry)
+	}
+	// Registo de outro tenant, nunca devolvido
+	repo.entries = append(repo.entries, &model.AuditLogEntry{
+		ID: uuid.New(), TenantID: uuid.New(), EventType: "ROLE_UPDATED", EntityType: "role",
+		Severity: model.AuditSeverityHigh, Details: "alteração externa", CreatedAt: base,
+	})
+	return repo
+}
+
+func TestAuditLogService_SearchPaginatesWithCursor(t *testing.T) {
+	tenantID, actorID := uuid.New(), uuid.New()
+	repo := newAuditLogFixture(tenantID, actorID, 25)
+	service := impl.NewAuditLogService(repo, impl.AuditLogConfig{PageSize: 10, MaxPageSize: 50})
+
+	// As páginas percorrem todos os registos, incluindo os do mesmo instante, sem repetições
+	seen := make(map[uuid.UUID]bool)
+	var previous *model.AuditLogEntry
+	filter := model.AuditLogFilter{}
+	pages := 0
+	for {
+		page, err := service.Search(context.Background(), tenantID, filter)
+		require.NoError(t, err)
+		pages++
+		for _, entry := range page.Entries {
+			assert.False(t, seen[entry.ID], "registo repetido")
+			seen[entry.ID] = true
+			if previous != nil {
+				assert.False(t, entry.CreatedAt.After(previous.CreatedAt))
+			}
+			previous = entry
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := model.ParseAuditLogCursor(page.NextCursor)
+		require.NoError(t, err)
+		filter.Cursor = cursor
+	}
+	assert.Equal(t, 3, pages)
+	assert.Len(t, seen, 25)
+	// Cada consulta pede um registo adicional para detetar a página seguinte
+	assert.Equal(t, []int{11, 11, 11}, repo.limits)
+
+	_, err := service.Search(context.Background(), tenantID, model.AuditLogFilter{Limit: 51})
+	assert.ErrorIs(t, err, application.ErrInvalidAuditLogFilter)
+}
+
+func TestAuditLogService_SearchFilters(t *testing.T) {
+	tenantID, actorID := uuid.New(), uuid.New()
+	repo := newAuditLogFixture(tenantID, actorID, 12)
+	service := impl.NewAuditLogService(repo, impl.DefaultAuditLogConfig())
+
+	page, err := service.Search(context.Background(), tenantID, model.AuditLogFilter{
+		ActorID:    &actorID,
+		Severities: []model.AuditSeverity{model.AuditSeverityHigh},
+		Market:     "MZ",
+	})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 6)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = service.Search(context.Background(), tenantID, model.AuditLogFilter{Query: "alteração 7"})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "AO", page.Entries[0].Market)
+
+	from := time.Date(2025, 5, 1, 12, 2, 0, 0, time.UTC)
+	to := from.Add(time.Minute)
+	page, err = service.Search(context.Background(), tenantID, model.AuditLogFilter{From: &from, To: &to})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 3)
+
+	invalid := []model.AuditLogFilter{
+		{From: &to, To: &from},
+		{Severities: []model.AuditSeverity{"urgent"}},
+		{TargetID: &actorID},
+		{Query: strings.Repeat("a", 257)},
+	}
+	for _, filter := range invalid {
+		_, err := service.Search(context.Background(), tenantID, filter)
+		assert.ErrorIs(t, err, application.ErrInvalidAuditLogFilter)
+	}
+
+	_, err = model.ParseAuditLogCursor("não-é-um-cursor")
+	assert.ErrorIs(t, err, model.ErrInvalidAuditLogFilter)
+}
+
+func TestAuditLogService_ExportCSV(t *testing.T) {
+	tenantID, actorID := uuid.New(), uuid.New()
+	repo := newAuditLogFixture(tenantID, actorID, 25)
+	service := impl.NewAuditLogService(repo, impl.AuditLogConfig{ExportBatchSize: 7, MaxExportRows: 100})
+
+	var buf bytes.Buffer
+	result, err := service.Export(context.Background(), tenantID, model.AuditLogFilter{Limit: 3}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 25, result.Rows)
+	assert.Empty(t, result.NextCursor)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 26)
+	assert.Equal(t, model.AuditLogCSVHeader, records[0])
+	ids := make(map[string]bool)
+	for _, record := range records[1:] {
+		ids[record[0]] = true
+	}
+	assert.Len(t, ids, 25)
+
+	// Acima do limite de linhas a exportação devolve o cursor para continuar
+	service = impl.NewAuditLogService(repo, impl.AuditLogConfig{ExportBatchSize: 7, MaxExportRows: 10})
+	buf.Reset()
+	result, err = service.Export(context.Background(), tenantID, model.AuditLogFilter{}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Rows)
+	require.NotEmpty(t, result.NextCursor)
+
+	records, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	first := make(map[string]bool)
+	for _, record := range records[1:] {
+		first[record[0]] = true
+	}
+
+	cursor, err := model.ParseAuditLogCursor(result.NextCursor)
+	require.NoError(t, err)
+	buf.Reset()
+	result, err = service.Export(context.Background(), tenantID, model.AuditLogFilter{Cursor: cursor}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Rows)
+
+	records, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 11)
+	for _, record := range records[1:] {
+		assert.False(t, first[record[0]], "registo exportado duas vezes")
+	}
+}
@@ -0,0 +1,666 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as operações em massa sobre usuários (BulkUserJobService).
+ * Valida a leitura do conteúdo NDJSON e CSV, o resultado por usuário, a retoma dos
+ * itens falhados e a interrupção da execução sem perda dos resultados já aplicados.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeBulkUserJobRepository é um BulkUserJobRepository em memória
+type fakeBulkUserJobRepository struct {
+	mu    sync.Mutex
+	jobs  map[uuid.UUID]*model.BulkUserJob
+	items map[uuid.UUID][]*model.BulkUserJobItem
+	saved int
+}
+
+func newFakeBulkUserJobRepository() *fakeBulkUserJobRepository {
+	return &fakeBulkUserJobRepository{
+		jobs:  make(map[uuid.UUID]*model.BulkUserJob),
+		items: make(map[uuid.UUID][]*model.BulkUserJobItem),
+	}
+}
+
+func (r *fakeBulkUserJobRepository) Create(ctx context.Context, job *model.BulkUserJob, items []*model.BulkUserJobItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *job
+	r.jobs[job.ID] = &copied
+	for _, item := range items {
+		stored := *item
+		r.items[job.ID] = append(r.items[job.ID], &stored)
+	}
+	sort.Slice(r.items[job.ID], func(i, j int) bool { return r.items[job.ID][i].Line < r.items[job.ID][j].Line })
+	return nil
+}
+
+func (r *fakeBulkUserJobRepository) Get(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok || job.TenantID != tenantID {
+		return nil, model.ErrBulkUserJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *fakeBulkUserJobRepository) List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.BulkUserJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var jobs []*model.BulkUserJob
+	for _, job := range r.jobs {
+		if job.TenantID == tenantID {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeBulkUserJobRepository) ListActive(ctx context.Context, limit int) ([]*model.BulkUserJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var jobs []*model.BulkUserJob
+	for _, job := range r.jobs {
+		if job.IsActive() {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeBulkUserJobRepository) ListItems(
+	ctx context.Context,
+	tenantID, jobID uuid.UUID,
+	status model.BulkUserItemStatus,
+	afterLine, limit int,
+) ([]*model.BulkUserJobItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []*model.BulkUserJobItem
+	for _, item := range r.items[jobID] {
+		if item.TenantID != tenantID || item.Line <= afterLine || (status != "" && item.Status != status) {
+			continue
+		}
+		copied := *item
+		items = append(items, &copied)
+		if len(items) == limit {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeBulkUserJobRepository) Save(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.save(job, expectedVersion)
+}
+
+func (r *fakeBulkUserJobRepository) SaveResults(
+	ctx context.Context,
+	job *model.BulkUserJob,
+	items []*model.BulkUserJobItem,
+	expectedVersion int64,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.save(job, expectedVersion); err != nil {
+		return err
+	}
+	for _, item := range items {
+		for i, stored := range r.items[job.ID] {
+			if stored.Line == item.Line && stored.Status == model.BulkUserItemStatusPending {
+				copied := *item
+				r.items[job.ID][i] = &copied
+			}
+		}
+	}
+	r.saved += len(items)
+	return nil
+}
+
+func (r *fakeBulkUserJobRepository) Requeue(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.save(job, expectedVersion); err != nil {
+		return err
+	}
+	for _, item := range r.items[job.ID] {
+		if item.Status == model.BulkUserItemStatusFailed {
+			item.Status = model.BulkUserItemStatusPending
+		}
+	}
+	return nil
+}
+
+func (r *fakeBulkUserJobRepository) save(job *model.BulkUserJob, expectedVersion int64) error {
+	stored, ok := r.jobs[job.ID]
+	if !ok || stored.Version != expectedVersion {
+		return model.ErrBulkUserJobConflict
+	}
+	job.Version = expectedVersion + 1
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}
+
+func (r *fakeBulkUserJobRepository) item(jobID uuid.UUID, line int) *model.BulkUserJobItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.items[jobID] {
+		if item.Line == line {
+			copied := *item
+			return &copied
+		}
+	}
+	return nil
+}
+
+// fakeBulkRoleService implementa as atribuições de funções usadas pelas operações em massa
+// As restantes operações de RoleService não são usadas e provocam pânico
+type fakeBulkRoleService struct {
+	application.RoleService
+
+	mu          sync.Mutex
+	assignments map[uuid.UUID]map[uuid.UUID]bool
+	missing     map[uuid.UUID]bool
+	calls       int
+	// onCall é invocado antes de cada atribuição ou revogação
+	onCall func(call int)
+}
+
+func newFakeBulkRoleService() *fakeBulkRoleService {
+	return &fakeBulkRoleService{
+		assignments: make(map[uuid.UUID]map[uuid.UUID]bool),
+		missing:     make(map[uuid.UUID]bool),
+	}
+}
+
+func (s *fakeBulkRoleService) AssignUserToRole(
+	ctx context.Context,
+	tenantID, roleID, userID uuid.UUID,
+	activatesAt time.Time,
+	expiresAt *time.Time,
+	assignedBy uuid.UUID,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.onCall != nil {
+		s.onCall(s.calls)
+	}
+	if s.missing[roleID] {
+		return application.ErrRoleNotFound
+	}
+	if s.assignments[roleID][userID] {
+		return application.ErrUserAlreadyAssigned
+	}
+	if s.assignments[roleID] == nil {
+		s.assignments[roleID] = make(map[uuid.UUID]bool)
+	}
+	s.assignments[roleID][userID] = true
+	return nil
+}
+
+func (s *fakeBulkRoleService) RevokeUserFromRole(ctx context.Context, tenantID, roleID, userID, revokedBy uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.onCall != nil {
+		s.onCall(s.calls)
+	}
+	if !s.assignments[roleID][userID] {
+		return application.ErrUserNotAssigned
+	}
+	delete(s.assignments[roleID], userID)
+	return nil
+}
+
+func (s *fakeBulkRoleService) assigned(roleID, userID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.assignments[roleID][userID]
+}
+
+// bulkUserJobFixture reúne o serviço de operações em massa e as suas dependências
+type bulkUserJobFixture struct {
+	repo    *fakeBulkUserJobRepository
+	users   *fakeUserLifecycleRepository
+	roles   *fakeBulkRoleService
+	service application.BulkUserJobService
+}
+
+func newBulkUserJobFixture(config impl.BulkUserJobConfig) *bulkUserJobFixture {
+	f := &bulkUserJobFixture{
+		repo:  newFakeBulkUserJobRepository(),
+		users: newFakeUserLifecycleRepository(),
+		roles: newFakeBulkRoleService(),
+	}
+	lifecycle := impl.NewUserLifecycleService(f.users, &recordingPublisher{}, model.UserLifecyclePolicy{})
+	f.service = impl.NewBulkUserJobService(f.repo, f.users, lifecycle, f.roles, config)
+	return f
+}
+
+// ndjson gera o conteúdo NDJSON com um objeto por usuário
+func ndjson(userIDs ...uuid.UUID) string {
+	var b strings.Builder
+	for _, userID := range userIDs {
+		fmt.Fprintf(&b, "{\"user_id\":%q}\n", userID)
+	}
+	return b.String()
+}
+
+func TestBulkUserJobService_SubmitNDJSON(t *testing.T) {
+	ctx := context.Background()
+	f := newBulkUserJobFixture(impl.BulkUserJobConfig{})
+	tenantID := uuid.New()
+	roleID := uuid.New()
+	otherRoleID := uuid.New()
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+
+	t.Run("valores da tarefa aplicam-se aos itens que não os indicam", func(t *testing.T) {
+		first, second := uuid.New(), uuid.New()
+		payload := fmt.Sprintf("{\"user_id\":%q}\n\n{\"user_id\":%q,\"role_id\":%q,\"reason\":\"projeto\"}\n", first, second, otherRoleID)
+
+		job, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationAssignRole,
+			RoleID:    &roleID,
+			Reason:    "migração",
+			ExpiresAt: &expiresAt,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(payload),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.BulkUserJobStatusPending, job.Status)
+		assert.Equal(t, 2, job.Total)
+
+		page, err := f.service.ListItems(ctx, tenantID, job.ID, "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+
+		assert.Equal(t, 1, page.Items[0].Line)
+		assert.Equal(t, roleID, *page.Items[0].RoleID)
+		assert.Equal(t, "migração", page.Items[0].Reason)
+		assert.Equal(t, expiresAt, *page.Items[0].ExpiresAt)
+
+		// As linhas em branco contam para a numeração das linhas
+		assert.Equal(t, 3, page.Items[1].Line)
+		assert.Equal(t, otherRoleID, *page.Items[1].RoleID)
+		assert.Equal(t, "projeto", page.Items[1].Reason)
+	})
+
+	t.Run("linha inválida recusa a tarefa indicando a linha", func(t *testing.T) {
+		payload := ndjson(uuid.New()) + "{\"user_id\":\"x\"}\n"
+
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(payload),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+		assert.Contains(t, err.Error(), "linha 2")
+	})
+
+	t.Run("campos desconhecidos são recusados", func(t *testing.T) {
+		payload := fmt.Sprintf("{\"user_id\":%q,\"role\":\"admin\"}\n", uuid.New())
+
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(payload),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+	})
+
+	t.Run("usuário repetido é recusado", func(t *testing.T) {
+		userID := uuid.New()
+
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(ndjson(userID, uuid.New(), userID)),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+		assert.Contains(t, err.Error(), "linha 3 repete o usuário da linha 1")
+	})
+
+	t.Run("desativação exige motivo", func(t *testing.T) {
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationDisable,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(ndjson(uuid.New())),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+	})
+
+	t.Run("número máximo de usuários", func(t *testing.T) {
+		limited := newBulkUserJobFixture(impl.BulkUserJobConfig{MaxItems: 2})
+
+		_, err := limited.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadNDJSON,
+			Payload:   strings.NewReader(ndjson(uuid.New(), uuid.New(), uuid.New())),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+	})
+}
+
+func TestBulkUserJobService_SubmitCSV(t *testing.T) {
+	ctx := context.Background()
+	f := newBulkUserJobFixture(impl.BulkUserJobConfig{})
+	tenantID := uuid.New()
+	roleID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	t.Run("colunas opcionais e campos entre aspas", func(t *testing.T) {
+		payload := "user_id,reason,expires_at\n" +
+			first.String() + ",\"revisão, trimestral\",2030-01-01T00:00:00Z\n" +
+			second.String() + ",,\n"
+
+		job, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationAssignRole,
+			RoleID:    &roleID,
+			Reason:    "migração",
+			Format:    application.BulkUserPayloadCSV,
+			Payload:   strings.NewReader(payload),
+		})
+		require.NoError(t, err)
+
+		page, err := f.service.ListItems(ctx, tenantID, job.ID, "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+
+		assert.Equal(t, 2, page.Items[0].Line)
+		assert.Equal(t, "revisão, trimestral", page.Items[0].Reason)
+		require.NotNil(t, page.Items[0].ExpiresAt)
+		assert.Equal(t, 2030, page.Items[0].ExpiresAt.Year())
+
+		assert.Equal(t, 3, page.Items[1].Line)
+		assert.Equal(t, "migração", page.Items[1].Reason)
+		assert.Nil(t, page.Items[1].ExpiresAt)
+	})
+
+	t.Run("coluna desconhecida é recusada", func(t *testing.T) {
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadCSV,
+			Payload:   strings.NewReader("user_id,email\n" + first.String() + ",ana@innovabiz.com\n"),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+		assert.Contains(t, err.Error(), "email")
+	})
+
+	t.Run("coluna user_id obrigatória", func(t *testing.T) {
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationEnable,
+			Format:    application.BulkUserPayloadCSV,
+			Payload:   strings.NewReader("reason\nteste\n"),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+	})
+
+	t.Run("valor inválido indica a linha", func(t *testing.T) {
+		payload := "user_id,expires_at\n" + first.String() + ",\n" + second.String() + ",amanhã\n"
+
+		_, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+			TenantID:  tenantID,
+			Operation: model.BulkUserOperationAssignRole,
+			RoleID:    &roleID,
+			Format:    application.BulkUserPayloadCSV,
+			Payload:   strings.NewReader(payload),
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+		assert.Contains(t, err.Error(), "linha 3")
+	})
+}
+
+func TestBulkUserJobService_RunDisable(t *testing.T) {
+	ctx := context.Background()
+	f := newBulkUserJobFixture(impl.BulkUserJobConfig{BatchSize: 2})
+	tenantID := uuid.New()
+	now := time.Now().UTC()
+
+	active := newLifecycleUser(t, tenantID, "ana", model.UserStatusActive, now)
+	otherActive := newLifecycleUser(t, tenantID, "bruno", model.UserStatusActive, now)
+	suspended := newLifecycleUser(t, tenantID, "carla", model.UserStatusSuspended, now)
+	deprovisioned := newLifecycleUser(t, tenantID, "diana", model.UserStatusDeprovisioned, now)
+	for _, user := range []*model.User{active, otherActive, suspended, deprovisioned} {
+		f.users.add(user)
+	}
+	unknown := uuid.New()
+
+	job, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+		TenantID:    tenantID,
+		RequestedBy: uuid.New(),
+		Operation:   model.BulkUserOperationDisable,
+		Reason:      "saída da empresa",
+		Format:      application.BulkUserPayloadNDJSON,
+		Payload:     strings.NewReader(ndjson(active.ID, suspended.ID, unknown, deprovisioned.ID, otherActive.ID)),
+	})
+	require.NoError(t, err)
+
+	job, err = f.service.Run(ctx, tenantID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BulkUserJobStatusCompletedWithErrors, job.Status)
+	assert.Equal(t, 5, job.Processed)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 1, job.Skipped)
+	assert.Equal(t, 2, job.Failed)
+	assert.Equal(t, 1, job.Attempts)
+	require.NotNil(t, job.CompletedAt)
+
+	assert.Equal(t, model.UserStatusSuspended, f.users.status(active.ID))
+	assert.Equal(t, model.UserStatusSuspended, f.users.status(otherActive.ID))
+	assert.Equal(t, model.UserStatusDeprovisioned, f.users.status(deprovisioned.ID))
+
+	assert.Equal(t, model.BulkUserItemCodeAlreadyApplied, f.repo.item(job.ID, 2).Code)
+	assert.Equal(t, model.BulkUserItemCodeUserNotFound, f.repo.item(job.ID, 3).Code)
+	assert.Equal(t, model.BulkUserItemCodeInvalidTransition, f.repo.item(job.ID, 4).Code)
+
+	failed, err := f.service.ListItems(ctx, tenantID, job.ID, model.BulkUserItemStatusFailed, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, failed.Items, 2)
+	assert.Equal(t, 3, failed.Items[0].Line)
+	assert.Equal(t, 4, failed.Items[1].Line)
+	assert.Zero(t, failed.NextAfter)
+
+	t.Run("tarefa concluída não volta a ser executada", func(t *testing.T) {
+		again, err := f.service.Run(ctx, tenantID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, job.Version, again.Version)
+	})
+
+	t.Run("paginação pela linha", func(t *testing.T) {
+		page, err := f.service.ListItems(ctx, tenantID, job.ID, "", 0, 2)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, 2, page.NextAfter)
+
+		page, err = f.service.ListItems(ctx, tenantID, job.ID, "", page.NextAfter, 2)
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, 3, page.Items[0].Line)
+	})
+
+	t.Run("resultado desconhecido é recusado", func(t *testing.T) {
+		_, err := f.service.ListItems(ctx, tenantID, job.ID, "done", 0, 0)
+		assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob)
+	})
+
+	t.Run("tarefa de outro tenant não é visível", func(t *testing.T) {
+		_, err := f.service.ListItems(ctx, uuid.New(), job.ID, "", 0, 0)
+		assert.ErrorIs(t, err, application.ErrBulkUserJobNotFound)
+	})
+}
+
+func TestBulkUserJobService_ResumeFailedItems(t *testing.T) {
+	ctx := context.Background()
+	f := newBulkUserJobFixture(impl.BulkUserJobConfig{})
+	tenantID := uuid.New()
+	now := time.Now().UTC()
+	roleID := uuid.New()
+
+	first := newLifecycleUser(t, tenantID, "ana", model.UserStatusActive, now)
+	second := newLifecycleUser(t, tenantID, "bruno", model.UserStatusActive, now)
+	third := newLifecycleUser(t, tenantID, "carla", model.UserStatusActive, now)
+	for _, user := range []*model.User{first, second, third} {
+		f.users.add(user)
+	}
+	f.roles.assignments[roleID] = map[uuid.UUID]bool{second.ID: true}
+	f.roles.missing[roleID] = true
+
+	job, err := f.service.Submit(ctx, &application.SubmitBulkUserJobRequest{
+		TenantID:  tenantID,
+		Operation: model.BulkUserOperationAssignRole,
+		RoleID:    &roleID,
+		Format:    application.BulkUserPayloadNDJSON,
+		Payload:   strings.NewReader(ndjson(first.ID, second.ID, third.ID)),
+	})
+	require.NoError(t, err)
+
+	_, err = f.service.Resume(ctx, tenantID, job.ID)
+	assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob, "tarefa pendente não pode ser retomada")
+
+	job, err = f.service.Run(ctx, tenantID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BulkUserJobStatusCompletedWithErrors, job.Status)
+	assert.Equal(t, 3, job.Failed)
+	assert.Equal(t, model.BulkUserItemCodeRoleNotFound, f.repo.item(job.ID, 1).Code)
+
+	// A função passa a existir e a tarefa é retomada
+	f.roles.missing[roleID] = false
+
+	job, err = f.service.Resume(ctx, tenantID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BulkUserJobStatusPending, job.Status)
+	assert.Zero(t, job.Processed)
+	assert.Zero(t, job.Failed)
+	assert.Nil(t, job.CompletedAt)
+
+	job, err = f.service.Run(ctx, tenantID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BulkUserJobStatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 1, job.Skipped)
+	assert.Equal(t, 2, job.Attempts)
+
+	assert.True(t, f.roles.assigned(roleID, first.ID))
+	assert.True(t, f.roles.assigned(roleID, third.ID))
+
+	item := f.repo.item(job.ID, 2)
+	assert.Equal(t, model.BulkUserItemStatusSkipped, item.Status)
+	assert.Equal(t, model.BulkUserItemCodeAlreadyApplied, item.Code)
+	assert.Equal(t, 2, item.Attempts)
+
+	_, err = f.service.Resume(ctx, tenantID, job.ID)
+	assert.ErrorIs(t, err, application.ErrInvalidBulkUserJob, "tarefa concluída sem erros não pode ser retomada")
+}
+
+func TestBulkUserJobService_RunCancelled(t *testing.T) {
+	f := newBulkUserJobFixture(impl.BulkUserJobConfig{BatchSize: 10})
+	tenantID := uuid.New()
+	now := time.Now().UTC()
+	roleID := uuid.New()
+
+	userIDs := make([]uuid.UUID, 0, 4)
+	for i := 0; i < 4; i++ {
+		user := newLifecycleUser(t, tenantID, fmt.Sprintf("user%d", i), model.UserStatusActive, now)
+		f.users.add(user)
+		userIDs = append(userIDs, user.ID)
+	}
+	f.roles.assignments[roleID] = make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		f.roles.assignments[roleID][userID] = true
+	}
+
+	job, err := f.service.Submit(context.Background(), &application.SubmitBulkUserJobRequest{
+		TenantID:  tenantID,
+		Operation: model.BulkUserOperationRevokeRole,
+		RoleID:    &roleID,
+		Format:    application.BulkUserPayloadNDJSON,
+		Payload:   strings.NewReader(ndjson(userIDs...)),
+	})
+	require.NoError(t, err)
+
+	// O processo é interrompido durante a revogação do terceiro usuário, que chega a ser aplicada
+	ctx, cancel := context.WithCancel(context.Background())
+	f.roles.onCall = func(call int) {
+		if call == 3 {
+			cancel()
+		}
+	}
+
+	interrupted, err := f.service.Run(ctx, tenantID, job.ID)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, model.BulkUserJobStatusRunning, interrupted.Status)
+	assert.Equal(t, 2, interrupted.Processed)
+	assert.Equal(t, 2, f.repo.saved)
+	assert.Equal(t, model.BulkUserItemStatusPending, f.repo.item(job.ID, 3).Status)
+
+	// A passagem seguinte retoma os itens pendentes; a revogação já aplicada é ignorada
+	f.roles.onCall = nil
+	result, err := f.service.RunPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed)
+	assert.Equal(t, 1, result.Completed)
+
+	job, err = f.service.Get(context.Background(), tenantID, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.BulkUserJobStatusCompleted, job.Status)
+	assert.Equal(t, 4, job.Processed)
+	assert.Equal(t, 3, job.Succeeded)
+	assert.Equal(t, 1, job.Skipped)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, model.BulkUserItemCodeAlreadyApplied, f.repo.item(job.ID, 3).Code)
+
+	for _, userID := range userIDs {
+		assert.False(t, f.roles.assigned(roleID, userID))
+	}
+}
@@ -0,0 +1,479 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o registo de dispositivos (DeviceService).
+ * Valida o registo dos dispositivos nos logins, a gestão pelo usuário, os limites da confiança,
+ * a decisão de confiança usada pelo MFA e a deteção de dispositivos partilhados entre contas.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeDeviceRepository é um DeviceRepository em memória
+type fakeDeviceRepository struct {
+	mu       sync.Mutex
+	policies map[uuid.UUID]*model.DevicePolicy
+	devices  map[uuid.UUID]*model.Device
+}
+
+func newFakeDeviceRepository() *fakeDeviceRepository {
+	return &fakeDeviceRepository{
+		policies: make(map[uuid.UUID]*model.DevicePolicy),
+		devices:  make(map[uuid.UUID]*model.Device),
+	}
+}
+
+func (r *fakeDeviceRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.DevicePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if policy, ok := r.policies[tenantID]; ok {
+		copied := *policy
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeDeviceRepository) SavePolicy(ctx context.Context, policy *model.DevicePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *policy
+	r.policies[policy.TenantID] = &copied
+	return nil
+}
+
+func (r *fakeDeviceRepository) RecordSighting(ctx context.Context, device *model.Device) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.devices {
+		if existing.TenantID == device.TenantID && existing.UserID == device.UserID &&
+			existing.Fingerprint == device.Fingerprint && existing.Status == model.DeviceStatusActive {
+			existing.LastIPAddress = device.LastIPAddress
+			existing.LastMethod = device.LastMethod
+			existing.LoginCount++
+			if device.LastSeenAt.After(existing.LastSeenAt) {
+				existing.LastSeenAt = device.LastSeenAt
+			}
+			*device = *existing
+			return false, nil
+		}
+	}
+	copied := *device
+	r.devices[device.ID] = &copied
+	return true, nil
+}
+
+func (r *fakeDeviceRepository) FindByFingerprint(ctx context.Context, tenantID, userID uuid.UUID, fingerprint string) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *model.Device
+	for _, device := range r.devices {
+		if device.TenantID != tenantID || device.UserID != userID || device.Fingerprint != fingerprint {
+			continue
+		}
+		if found == nil || device.Status == model.DeviceStatusActive {
+			found = device
+		}
+	}
+	if found == nil {
+		return nil, model.ErrDeviceNotFound
+	}
+	copied := *found
+	return &copied, nil
+}
+
+func (r *fakeDeviceRepository) ListAccountsByReuseKey(ctx context.Context, tenantID uuid.UUID, reuseKey string, since time.Time) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[uuid.UUID]bool)
+	var accounts []uuid.UUID
+	for _, device := range r.devices {
+		if device.TenantID != tenantID || device.ReuseKey != reuseKey || device.Status != model.DeviceStatusActive ||
+			device.LastSeenAt.Before(since) || seen[device.UserID] {
+			continue
+		}
+		seen[device.UserID] = true
+		accounts = append(accounts, device.UserID)
+	}
+	return accounts, nil
+}
+
+func (r *fakeDeviceRepository) List(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var devices []*model.Device
+	for _, device := range r.devices {
+		if device.TenantID == tenantID && device.UserID == userID {
+			copied := *device
+			devices = append(devices, &copied)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].LastSeenAt.After(devices[j].LastSeenAt) })
+	return devices, nil
+}
+
+func (r *fakeDeviceRepository) Get(ctx context.Context, tenantID, userID, deviceID uuid.UUID) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	device, ok := r.devices[deviceID]
+	if !ok || device.TenantID != tenantID || device.UserID != userID {
+		return nil, model.ErrDeviceNotFound
+	}
+	copied := *device
+	return &copied, nil
+}
+
+func (r *fakeDeviceRepository) Update(ctx context.Context, device *model.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.devices[device.ID]; !ok {
+		return model.ErrDeviceNotFound
+	}
+	copied := *device
+	r.devices[device.ID] = &copied
+	return nil
+}
+
+// expireTrust antecipa o fim da confiança no dispositivo
+func (r *fakeDeviceRepository) expireTrust(deviceID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := time.Now().Add(-time.Minute)
+	r.devices[deviceID].TrustExpiresAt = &expired
+}
+
+// deviceFixture reúne o serviço e os seus colaboradores em memória
+type deviceFixture struct {
+	service   application.DeviceService
+	repo      *fakeDeviceRepository
+	incidents *fakeSecurityIncidentRepository
+	publisher *recordingPublisher
+	tenantID  uuid.UUID
+	userID    uuid.UUID
+	adminID   uuid.UUID
+	// clock avança a cada login, para que a ordem dos dispositivos listados seja determinística
+	clock time.Time
+}
+
+func newDeviceFixture(t *testing.T) *deviceFixture {
+	t.Helper()
+
+	f := &deviceFixture{
+		repo:      newFakeDeviceRepository(),
+		incidents: newFakeSecurityIncidentRepository(),
+		publisher: &recordingPublisher{},
+		tenantID:  uuid.New(),
+		userID:    uuid.New(),
+		adminID:   uuid.New(),
+		clock:     time.Now().UTC().Add(-time.Hour),
+	}
+	config := impl.DefaultDeviceConfig()
+	config.SigningKey = []byte("chave-de-teste-das-impressoes-dos-dispositivos")
+	f.service = impl.NewDeviceService(f.repo, f.incidents, f.publisher, config)
+	return f
+}
+
+// enableTrust ativa a confiança em dispositivos no tenant
+func (f *deviceFixture) enableTrust(t *testing.T, maxTrusted int) {
+	t.Helper()
+
+	_, err := f.service.UpdatePolicy(context.Background(), &application.UpdateDevicePolicyRequest{
+		TenantID:          f.tenantID,
+		TrustEnabled:      true,
+		TrustDays:         30,
+		MaxTrustedDevices: maxTrusted,
+		ReuseThreshold:    3,
+		UpdatedBy:         f.adminID,
+	})
+	require.NoError(t, err)
+}
+
+// login regista um login do usuário a partir do dispositivo e retorna o dispositivo registado
+func (f *deviceFixture) login(t *testing.T, userID uuid.UUID, deviceID string) *model.Device {
+	t.Helper()
+
+	evt := f.loginEvent(userID, deviceID)
+	require.NoError(t, f.service.ObserveLogin(context.Background(), evt))
+
+	devices, err := f.service.ListDevices(context.Background(), f.tenantID, userID)
+	require.NoError(t, err)
+	require.NotEmpty(t, devices)
+	return devices[0]
+}
+
+func (f *deviceFixture) loginEvent(userID uuid.UUID, deviceID string) *model.LoginEvent {
+	f.clock = f.clock.Add(time.Second)
+	return &model.LoginEvent{
+		TenantID:   f.tenantID,
+		UserID:     userID,
+		Method:     "magic_link",
+		DeviceID:   deviceID,
+		IPAddress:  "198.51.100.7",
+		UserAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		Country:    "pt",
+		OccurredAt: f.clock,
+	}
+}
+
+func (f *deviceFixture) action(userID, deviceID uuid.UUID) *application.DeviceActionRequest {
+	return &application.DeviceActionRequest{TenantID: f.tenantID, UserID: userID, DeviceID: deviceID, ActorID: userID}
+}
+
+// publishedTopics retorna os tópicos dos eventos de dispositivos publicados
+func (f *deviceFixture) publishedTopics() []string {
+	var topics []string
+	for _, evt := range f.publisher.published() {
+		if deviceEvent, ok := evt.(*event.DeviceEvent); ok {
+			topics = append(topics, deviceEvent.GetType())
+		}
+	}
+	return topics
+}
+
+func TestDeviceService_RegistersDevicesOnLogin(t *testing.T) {
+	f := newDeviceFixture(t)
+
+	device := f.login(t, f.userID, "device-1")
+	assert.Equal(t, "Firefox (Windows)", device.Name)
+	assert.True(t, device.Identified)
+	assert.Equal(t, "PT", device.LastCountry)
+	assert.Equal(t, 1, device.LoginCount)
+	assert.Equal(t, model.DeviceStatusActive, device.Status)
+	assert.NotEqual(t, "device-1", device.Fingerprint, "apenas o HMAC do identificador é gravado")
+
+	again := f.login(t, f.userID, "device-1")
+	assert.Equal(t, device.ID, again.ID)
+	assert.Equal(t, 2, again.LoginCount)
+
+	f.login(t, f.userID, "")
+	devices, err := f.service.ListDevices(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+
+	assert.Equal(t, []string{event.TopicDeviceRegistered, event.TopicDeviceRegistered}, f.publishedTopics(),
+		"apenas os dispositivos novos são publicados")
+
+	// Outro usuário com o mesmo dispositivo tem um registo próprio
+	other := f.login(t, uuid.New(), "device-1")
+	assert.NotEqual(t, device.ID, other.ID)
+}
+
+func TestDeviceService_RenameAndRevoke(t *testing.T) {
+	f := newDeviceFixture(t)
+	ctx := context.Background()
+	device := f.login(t, f.userID, "device-1")
+
+	renamed, err := f.service.RenameDevice(ctx, f.action(f.userID, device.ID), "  Portátil   do trabalho ")
+	require.NoError(t, err)
+	assert.Equal(t, "Portátil do trabalho", renamed.Name)
+
+	_, err = f.service.RenameDevice(ctx, f.action(f.userID, device.ID), " ")
+	assert.True(t, errors.Is(err, model.ErrInvalidDevice))
+
+	_, err = f.service.RenameDevice(ctx, f.action(uuid.New(), device.ID), "Outro")
+	assert.True(t, errors.Is(err, model.ErrDeviceNotFound), "o dispositivo de outro usuário não é encontrado")
+
+	revoked, err := f.service.RevokeDevice(ctx, f.action(f.userID, device.ID))
+	require.NoError(t, err)
+	assert.Equal(t, model.DeviceStatusRevoked, revoked.Status)
+	require.NotNil(t, revoked.RevokedBy)
+	assert.Equal(t, f.userID, *revoked.RevokedBy)
+
+	// O login seguinte a partir do dispositivo revogado regista um dispositivo novo
+	fresh := f.login(t, f.userID, "device-1")
+	assert.NotEqual(t, device.ID, fresh.ID)
+	assert.Equal(t, model.DeviceStatusActive, fresh.Status)
+
+	assert.Equal(t, []string{
+		event.TopicDeviceRegistered, event.TopicDeviceRenamed, event.TopicDeviceRevoked, event.TopicDeviceRegistered,
+	}, f.publishedTopics())
+}
+
+func TestDeviceService_TrustLimits(t *testing.T) {
+	f := newDeviceFixture(t)
+	ctx := context.Background()
+	first := f.login(t, f.userID, "device-1")
+	second := f.login(t, f.userID, "device-2")
+	unidentified := f.login(t, f.userID, "")
+
+	_, err := f.service.TrustDevice(ctx, f.action(f.userID, first.ID))
+	assert.True(t, errors.Is(err, model.ErrDeviceTrustDisabled), "sem política a confiança está desativada")
+
+	f.enableTrust(t, 1)
+
+	trusted, err := f.service.TrustDevice(ctx, f.action(f.userID, first.ID))
+	require.NoError(t, err)
+	require.NotNil(t, trusted.TrustExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), *trusted.TrustExpiresAt, time.Minute)
+
+	_, err = f.service.TrustDevice(ctx, f.action(f.userID, second.ID))
+	assert.True(t, errors.Is(err, model.ErrTrustedDeviceLimit))
+
+	_, err = f.service.TrustDevice(ctx, f.action(f.userID, unidentified.ID))
+	assert.True(t, errors.Is(err, model.ErrDeviceTrustForbidden), "os dispositivos sem identificador não podem ser de confiança")
+
+	// A confiança expirada não conta para o limite
+	f.repo.expireTrust(first.ID)
+	_, err = f.service.TrustDevice(ctx, f.action(f.userID, second.ID))
+	require.NoError(t, err)
+
+	untrusted, err := f.service.UntrustDevice(ctx, f.action(f.userID, second.ID))
+	require.NoError(t, err)
+	assert.Nil(t, untrusted.TrustedAt)
+	assert.Nil(t, untrusted.TrustExpiresAt)
+
+	_, err = f.service.RevokeDevice(ctx, f.action(f.userID, second.ID))
+	require.NoError(t, err)
+	_, err = f.service.TrustDevice(ctx, f.action(f.userID, second.ID))
+	assert.True(t, errors.Is(err, model.ErrDeviceRevoked))
+}
+
+func TestDeviceService_EvaluateDeviceTrust(t *testing.T) {
+	f := newDeviceFixture(t)
+	ctx := context.Background()
+	device := f.login(t, f.userID, "device-1")
+
+	decide := func(deviceID string) *model.DeviceTrustDecision {
+		t.Helper()
+		decision, err := f.service.EvaluateDeviceTrust(ctx, f.loginEvent(f.userID, deviceID))
+		require.NoError(t, err)
+		return decision
+	}
+
+	assert.Equal(t, model.DeviceTrustReasonPolicyOff, decide("device-1").Reason)
+
+	f.enableTrust(t, 5)
+	assert.Equal(t, model.DeviceTrustReasonUnidentified, decide("").Reason)
+	assert.Equal(t, model.DeviceTrustReasonUnknown, decide("device-9").Reason)
+	assert.Equal(t, model.DeviceTrustReasonNotTrusted, decide("device-1").Reason)
+
+	_, err := f.service.TrustDevice(ctx, f.action(f.userID, device.ID))
+	require.NoError(t, err)
+	decision := decide("device-1")
+	assert.True(t, decision.Trusted)
+	assert.Equal(t, model.DeviceTrustReasonTrusted, decision.Reason)
+	require.NotNil(t, decision.DeviceID)
+	assert.Equal(t, device.ID, *decision.DeviceID)
+
+	f.repo.expireTrust(device.ID)
+	decision = decide("device-1")
+	assert.False(t, decision.Trusted)
+	assert.Equal(t, model.DeviceTrustReasonExpired, decision.Reason)
+
+	_, err = f.service.RevokeDevice(ctx, f.action(f.userID, device.ID))
+	require.NoError(t, err)
+	assert.Equal(t, model.DeviceTrustReasonRevoked, decide("device-1").Reason)
+}
+
+func TestDeviceService_FingerprintReuseAcrossAccounts(t *testing.T) {
+	f := newDeviceFixture(t)
+	ctx := context.Background()
+	f.enableTrust(t, 5)
+
+	device := f.login(t, f.userID, "shared-device")
+	_, err := f.service.TrustDevice(ctx, f.action(f.userID, device.ID))
+	require.NoError(t, err)
+
+	second := uuid.New()
+	f.login(t, second, "shared-device")
+	// Os dispositivos reconhecidos apenas pelo agente não contam para a partilha
+	f.login(t, uuid.New(), "")
+	assert.NotContains(t, f.publishedTopics(), event.TopicDeviceFingerprintReuse)
+
+	third := uuid.New()
+	flagged := f.login(t, third, "shared-device")
+	assert.NotNil(t, flagged.ReuseDetectedAt)
+
+	var reuse *event.DeviceEvent
+	for _, evt := range f.publisher.published() {
+		if deviceEvent, ok := evt.(*event.DeviceEvent); ok && deviceEvent.GetType() == event.TopicDeviceFingerprintReuse {
+			reuse = deviceEvent
+		}
+	}
+	require.NotNil(t, reuse)
+	assert.ElementsMatch(t, []uuid.UUID{f.userID, second, third}, reuse.SharedWith)
+
+	incidents, err := f.incidents.List(ctx, f.tenantID, model.SecurityIncidentFilter{})
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	assert.Equal(t, model.SecurityIncidentRuleDeviceFingerprintReuse, incidents[0].Rule)
+	assert.Equal(t, model.SecurityIncidentSeverityMedium, incidents[0].Severity)
+	assert.ElementsMatch(t, []uuid.UUID{f.userID, second, third}, incidents[0].Evidence[0].SubjectIDs)
+
+	// O dispositivo partilhado deixa de dispensar o MFA, mesmo na conta que já confiava nele
+	decision, err := f.service.EvaluateDeviceTrust(ctx, f.loginEvent(f.userID, "shared-device"))
+	require.NoError(t, err)
+	assert.False(t, decision.Trusted)
+	assert.Equal(t, model.DeviceTrustReasonShared, decision.Reason)
+
+	_, err = f.service.TrustDevice(ctx, f.action(third, flagged.ID))
+	assert.True(t, errors.Is(err, model.ErrDeviceTrustForbidden))
+}
+
+func TestDeviceService_PolicyValidation(t *testing.T) {
+	f := newDeviceFixture(t)
+	ctx := context.Background()
+
+	policy, err := f.service.GetPolicy(ctx, f.tenantID)
+	require.NoError(t, err)
+	assert.False(t, policy.TrustEnabled)
+	assert.Equal(t, model.DefaultDeviceTrustDays, policy.TrustDays)
+
+	for _, req := range []*application.UpdateDevicePolicyRequest{
+		{TenantID: f.tenantID, TrustDays: 0, MaxTrustedDevices: 5, ReuseThreshold: 3},
+		{TenantID: f.tenantID, TrustDays: model.MaxDeviceTrustDays + 1, MaxTrustedDevices: 5, ReuseThreshold: 3},
+		{TenantID: f.tenantID, TrustDays: 30, MaxTrustedDevices: 0, ReuseThreshold: 3},
+		{TenantID: f.tenantID, TrustDays: 30, MaxTrustedDevices: 5, ReuseThreshold: 1},
+	} {
+		_, err := f.service.UpdatePolicy(ctx, req)
+		assert.True(t, errors.Is(err, model.ErrInvalidDevicePolicy), "%+v", req)
+	}
+}
+
+func TestLoginObservers_NotifiesAllObservers(t *testing.T) {
+	f := newDeviceFixture(t)
+	failing := loginObserverFunc(func(ctx context.Context, evt *model.LoginEvent) error {
+		return errors.New("falha do observador")
+	})
+
+	observers := application.LoginObservers{failing, nil, f.service}
+	err := observers.ObserveLogin(context.Background(), f.loginEvent(f.userID, "device-1"))
+	assert.Error(t, err)
+
+	devices, listErr := f.service.ListDevices(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, listErr)
+	assert.Len(t, devices, 1, "a falha de um observador não impede os seguintes")
+}
+
+// loginObserverFunc adapta uma função a LoginObserver
+type loginObserverFunc func(ctx context.Context, evt *model.LoginEvent) error
+
+func (fn loginObserverFunc) ObserveLogin(ctx context.Context, evt *model.LoginEvent) error {
+	return fn(ctx, evt)
+}
@@ -0,0 +1,502 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as contas de emergência (EmergencyAccessService).
+ * Valida o controlo duplo das aprovações, a selagem das contas fora das ativações, o registo
+ * encadeado das ações, as desativações automáticas e as regras da revisão posterior.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeEmergencyAccessRepository é um EmergencyAccessRepository em memória
+type fakeEmergencyAccessRepository struct {
+	mu          sync.Mutex
+	accounts    map[uuid.UUID]*model.EmergencyAccount
+	activations map[uuid.UUID]*model.EmergencyActivation
+	actions     map[uuid.UUID][]*model.EmergencyAction
+	appendErr   error
+}
+
+func newFakeEmergencyAccessRepository() *fakeEmergencyAccessRepository {
+	return &fakeEmergencyAccessRepository{
+		accounts:    make(map[uuid.UUID]*model.EmergencyAccount),
+		activations: make(map[uuid.UUID]*model.EmergencyActivation),
+		actions:     make(map[uuid.UUID][]*model.EmergencyAction),
+	}
+}
+
+func (r *fakeEmergencyAccessRepository) CreateAccount(ctx context.Context, account *model.EmergencyAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.accounts {
+		if existing.TenantID == account.TenantID && (existing.UserID == account.UserID || existing.Name == account.Name) {
+			return model.ErrEmergencyAccountConflict
+		}
+	}
+	copied := *account
+	r.accounts[account.ID] = &copied
+	return nil
+}
+
+func (r *fakeEmergencyAccessRepository) GetAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.EmergencyAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[accountID]
+	if !ok || account.TenantID != tenantID {
+		return nil, model.ErrEmergencyAccountNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r *fakeEmergencyAccessRepository) ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]*model.EmergencyAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var accounts []*model.EmergencyAccount
+	for _, account := range r.accounts {
+		if account.TenantID == tenantID {
+			copied := *account
+			accounts = append(accounts, &copied)
+		}
+	}
+	return accounts, nil
+}
+
+func (r *fakeEmergencyAccessRepository) CreateActivation(ctx context.Context, activation *model.EmergencyActivation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.activations {
+		if existing.AccountID == activation.AccountID &&
+			(existing.Status == model.EmergencyActivationPending || existing.Status == model.EmergencyActivationActive) {
+			return model.ErrEmergencyActivationOpen
+		}
+	}
+	copied := *activation
+	r.activations[activation.ID] = &copied
+	return nil
+}
+
+func (r *fakeEmergencyAccessRepository) GetActivation(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyActivation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	activation, ok := r.activations[activationID]
+	if !ok || activation.TenantID != tenantID {
+		return nil, model.ErrEmergencyActivationNotFound
+	}
+	copied := *activation
+	return &copied, nil
+}
+
+func (r *fakeEmergencyAccessRepository) ListActivations(ctx context.Context, tenantID uuid.UUID, status model.EmergencyActivationStatus) ([]*model.EmergencyActivation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var activations []*model.EmergencyActivation
+	for _, activation := range r.activations {
+		if activation.TenantID == tenantID && (status == "" || activation.Status == status) {
+			copied := *activation
+			activations = append(activations, &copied)
+		}
+	}
+	return activations, nil
+}
+
+func (r *fakeEmergencyAccessRepository) UpdateActivation(ctx context.Context, activation *model.EmergencyActivation, from model.EmergencyActivationStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.activations[activation.ID]
+	if !ok || existing.TenantID != activation.TenantID {
+		return model.ErrEmergencyActivationNotFound
+	}
+	if existing.Status != from {
+		return model.ErrEmergencyActivationState
+	}
+	copied := *activation
+	r.activations[activation.ID] = &copied
+	return nil
+}
+
+func (r *fakeEmergencyAccessRepository) ListDueActivations(ctx context.Context, now, pendingBefore time.Time, limit int) ([]*model.EmergencyActivation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var activations []*model.EmergencyActivation
+	for _, activation := range r.activations {
+		due := (activation.Status == model.EmergencyActivationActive && activation.ExpiresAt != nil && !activation.ExpiresAt.After(now)) ||
+			(activation.Status == model.EmergencyActivationPending && !activation.RequestedAt.After(pendingBefore))
+		if due && len(activations) < limit {
+			copied := *activation
+			activations = append(activations, &copied)
+		}
+	}
+	return activations, nil
+}
+
+func (r *fakeEmergencyAccessRepository) AppendAction(ctx context.Context, action *model.EmergencyAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.appendErr != nil {
+		return r.appendErr
+	}
+	activation, ok := r.activations[action.ActivationID]
+	if !ok || activation.Status != model.EmergencyActivationActive {
+		return model.ErrEmergencyActivationState
+	}
+
+	var previous *model.EmergencyAction
+	if actions := r.actions[action.ActivationID]; len(actions) > 0 {
+		previous = actions[len(actions)-1]
+	}
+	action.Chain(previous)
+	copied := *action
+	r.actions[action.ActivationID] = append(r.actions[action.ActivationID], &copied)
+	return nil
+}
+
+func (r *fakeEmergencyAccessRepository) ListActions(ctx context.Context, tenantID, activationID uuid.UUID, afterSequence int64, limit int) ([]*model.EmergencyAction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var actions []*model.EmergencyAction
+	for _, action := range r.actions[activationID] {
+		if action.TenantID == tenantID && action.Sequence > afterSequence && len(actions) < limit {
+			copied := *action
+			actions = append(actions, &copied)
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Sequence < actions[j].Sequence })
+	return actions, nil
+}
+
+// setActivation altera diretamente a ativação gravada, simulando a passagem do tempo
+func (r *fakeEmergencyAccessRepository) setActivation(activationID uuid.UUID, change func(*model.EmergencyActivation)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change(r.activations[activationID])
+}
+
+type emergencyAccessFixture struct {
+	repo      *fakeEmergencyAccessRepository
+	service   application.EmergencyAccessService
+	tenantID  uuid.UUID
+	userID    uuid.UUID
+	requester uuid.UUID
+	approver  uuid.UUID
+	reviewer  uuid.UUID
+	account   *model.EmergencyAccount
+}
+
+func newEmergencyAccessFixture(t *testing.T) *emergencyAccessFixture {
+	f := &emergencyAccessFixture{
+		repo:      newFakeEmergencyAccessRepository(),
+		tenantID:  uuid.New(),
+		userID:    uuid.New(),
+		requester: uuid.New(),
+		approver:  uuid.New(),
+		reviewer:  uuid.New(),
+	}
+	f.service = impl.NewEmergencyAccessService(f.repo, impl.DefaultEmergencyAccessConfig())
+
+	account, err := f.service.ProvisionAccount(context.Background(), &application.ProvisionEmergencyAccountRequest{
+		TenantID: f.tenantID,
+		UserID:   f.userID,
+		Name:     "root-emergencia",
+		ActorID:  f.requester,
+	})
+	require.NoError(t, err)
+	f.account = account
+	return f
+}
+
+func (f *emergencyAccessFixture) request(t *testing.T) *model.EmergencyActivation {
+	activation, err := f.service.RequestActivation(context.Background(), &application.RequestEmergencyActivationRequest{
+		TenantID:      f.tenantID,
+		AccountID:     f.account.ID,
+		IncidentRef:   "INC-2024-001",
+		Justification: "Base de dados principal indisponível e sem acesso administrativo",
+		RequestedBy:   f.requester,
+	})
+	require.NoError(t, err)
+	return activation
+}
+
+func (f *emergencyAccessFixture) activate(t *testing.T) *model.EmergencyActivation {
+	activation := f.request(t)
+	approved, err := f.service.ApproveActivation(context.Background(), f.tenantID, activation.ID, f.approver)
+	require.NoError(t, err)
+	return approved
+}
+
+func (f *emergencyAccessFixture) authorize(t *testing.T, userID uuid.UUID, path string) *model.EmergencyAccessDecision {
+	decision, err := f.service.Authorize(context.Background(), &model.EmergencyAccessRequest{
+		TenantID:  f.tenantID,
+		UserID:    userID,
+		Method:    "DELETE",
+		Path:      path,
+		IPAddress: "10.0.0.5",
+	})
+	require.NoError(t, err)
+	return decision
+}
+
+func TestEmergencyAccessService_SealedWithoutActivation(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+
+	assert.True(t, f.authorize(t, uuid.New(), "/api/v1/roles").Allowed)
+
+	decision := f.authorize(t, f.userID, "/api/v1/roles")
+	assert.False(t, decision.Allowed)
+	assert.True(t, decision.EmergencyAccount)
+	assert.Equal(t, model.EmergencyDenyReasonSealed, decision.Reason)
+
+	// Um pedido pendente não abre a conta
+	f.request(t)
+	assert.False(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+}
+
+func TestEmergencyAccessService_DualControl(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+
+	_, err := f.service.RequestActivation(context.Background(), &application.RequestEmergencyActivationRequest{
+		TenantID:      f.tenantID,
+		AccountID:     f.account.ID,
+		IncidentRef:   "INC-2024-001",
+		Justification: "Base de dados principal indisponível e sem acesso administrativo",
+		RequestedBy:   f.userID,
+	})
+	assert.ErrorIs(t, err, model.ErrEmergencyDualControl, "a própria conta não pode pedir a ativação")
+
+	activation := f.request(t)
+	_, err = f.service.ApproveActivation(context.Background(), f.tenantID, activation.ID, f.requester)
+	assert.ErrorIs(t, err, model.ErrEmergencyDualControl)
+	_, err = f.service.ApproveActivation(context.Background(), f.tenantID, activation.ID, f.userID)
+	assert.ErrorIs(t, err, model.ErrEmergencyDualControl)
+
+	_, err = f.service.RequestActivation(context.Background(), &application.RequestEmergencyActivationRequest{
+		TenantID:      f.tenantID,
+		AccountID:     f.account.ID,
+		IncidentRef:   "INC-2024-002",
+		Justification: "Segundo pedido enquanto o primeiro está pendente",
+		RequestedBy:   f.approver,
+	})
+	assert.ErrorIs(t, err, model.ErrEmergencyActivationOpen)
+
+	approved, err := f.service.ApproveActivation(context.Background(), f.tenantID, activation.ID, f.approver)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmergencyActivationActive, approved.Status)
+	require.NotNil(t, approved.ExpiresAt)
+	assert.WithinDuration(t, approved.DecidedAt.Add(impl.DefaultEmergencyActivationDuration), *approved.ExpiresAt, time.Second)
+}
+
+func TestEmergencyAccessService_RecordsActions(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+
+	// A conta está selada em cache; a aprovação invalida o cache
+	assert.False(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+	activation := f.activate(t)
+
+	first := f.authorize(t, f.userID, "/api/v1/roles/1")
+	assert.True(t, first.Allowed)
+	require.NotNil(t, first.ActivationID)
+	assert.Equal(t, activation.ID, *first.ActivationID)
+	assert.True(t, f.authorize(t, f.userID, "/api/v1/roles/2").Allowed)
+
+	actions, err := f.service.ListActions(context.Background(), f.tenantID, activation.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, int64(1), actions[0].Sequence)
+	assert.Equal(t, "/api/v1/roles/1", actions[0].Path)
+	assert.Equal(t, actions[0].Hash, actions[1].PrevHash)
+
+	verification, err := f.service.VerifyActions(context.Background(), f.tenantID, activation.ID)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 2, verification.Actions)
+	assert.Equal(t, actions[1].Hash, verification.HeadHash)
+
+	// Adulterar um registo quebra a cadeia a partir dele
+	f.repo.mu.Lock()
+	f.repo.actions[activation.ID][1].Path = "/api/v1/roles/3"
+	f.repo.mu.Unlock()
+
+	verification, err = f.service.VerifyActions(context.Background(), f.tenantID, activation.ID)
+	require.NoError(t, err)
+	assert.False(t, verification.Valid)
+	assert.Equal(t, int64(2), verification.BrokenAtSequence)
+	assert.Equal(t, 1, verification.Actions)
+}
+
+func TestEmergencyAccessService_RecordingFailureDenies(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+	f.activate(t)
+
+	f.repo.appendErr = errors.New("base de dados indisponível")
+	decision := f.authorize(t, f.userID, "/api/v1/roles")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.EmergencyDenyReasonRecordingFailed, decision.Reason)
+}
+
+func TestEmergencyAccessService_EndedElsewhereSeals(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+	activation := f.activate(t)
+	assert.True(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+
+	// A ativação termina noutra instância; o cache desta ainda a considera em vigor
+	f.repo.setActivation(activation.ID, func(a *model.EmergencyActivation) {
+		a.Status = model.EmergencyActivationClosed
+	})
+
+	decision := f.authorize(t, f.userID, "/api/v1/roles")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.EmergencyDenyReasonSealed, decision.Reason)
+}
+
+func TestEmergencyAccessService_ScheduledDeactivations(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+	activation := f.activate(t)
+	assert.True(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+
+	expiresAt := time.Now().UTC().Add(-time.Minute)
+	f.repo.setActivation(activation.ID, func(a *model.EmergencyActivation) {
+		a.ExpiresAt = &expiresAt
+	})
+
+	result, err := f.service.RunScheduledDeactivations(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Expired)
+	assert.Equal(t, 0, result.ApprovalsTimedOut)
+
+	expired, err := f.service.GetActivation(context.Background(), f.tenantID, activation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmergencyActivationExpired, expired.Status)
+	assert.Nil(t, expired.EndedBy)
+	require.NotNil(t, expired.EndedAt)
+	assert.True(t, expired.EndedAt.Equal(expiresAt))
+	assert.False(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+
+	// Os pedidos não aprovados no prazo são recusados
+	pending := f.request(t)
+	f.repo.setActivation(pending.ID, func(a *model.EmergencyActivation) {
+		a.RequestedAt = a.RequestedAt.Add(-2 * impl.DefaultEmergencyApprovalTimeout)
+	})
+	_, err = f.service.ApproveActivation(context.Background(), f.tenantID, pending.ID, f.approver)
+	assert.ErrorIs(t, err, model.ErrEmergencyActivationState)
+
+	result, err = f.service.RunScheduledDeactivations(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Expired)
+	assert.Equal(t, 1, result.ApprovalsTimedOut)
+
+	rejected, err := f.service.GetActivation(context.Background(), f.tenantID, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmergencyActivationRejected, rejected.Status)
+}
+
+func TestEmergencyAccessService_Review(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+	activation := f.activate(t)
+
+	review := func(reviewerID uuid.UUID, outcome model.EmergencyReviewOutcome, notes string) (*model.EmergencyActivation, error) {
+		return f.service.ReviewActivation(context.Background(), &application.ReviewEmergencyActivationRequest{
+			TenantID:     f.tenantID,
+			ActivationID: activation.ID,
+			Outcome:      outcome,
+			Notes:        notes,
+			ReviewerID:   reviewerID,
+		})
+	}
+
+	_, err := review(f.reviewer, model.EmergencyReviewJustified, "")
+	assert.ErrorIs(t, err, model.ErrEmergencyActivationState, "uma ativação em vigor não pode ser revista")
+
+	closed, err := f.service.CloseActivation(context.Background(), f.tenantID, activation.ID, f.requester, "incidente resolvido")
+	require.NoError(t, err)
+	assert.Equal(t, model.EmergencyActivationClosed, closed.Status)
+	assert.False(t, f.authorize(t, f.userID, "/api/v1/roles").Allowed)
+
+	pending, err := f.service.ListActivations(context.Background(), f.tenantID, model.EmergencyActivationClosed)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	_, err = review(f.requester, model.EmergencyReviewJustified, "")
+	assert.ErrorIs(t, err, model.ErrEmergencyDualControl)
+	_, err = review(f.approver, model.EmergencyReviewJustified, "")
+	assert.ErrorIs(t, err, model.ErrEmergencyDualControl)
+	_, err = review(f.reviewer, model.EmergencyReviewUnjustified, "sem motivo")
+	assert.ErrorIs(t, err, model.ErrInvalidEmergencyActivation, "um uso injustificado exige notas")
+
+	reviewed, err := review(f.reviewer, model.EmergencyReviewUnjustified, "Alterações feitas fora do âmbito do incidente")
+	require.NoError(t, err)
+	assert.Equal(t, model.EmergencyActivationReviewed, reviewed.Status)
+	assert.Equal(t, model.EmergencyReviewUnjustified, reviewed.ReviewOutcome)
+	require.NotNil(t, reviewed.ReviewedBy)
+	assert.Equal(t, f.reviewer, *reviewed.ReviewedBy)
+
+	_, err = review(f.reviewer, model.EmergencyReviewJustified, "")
+	assert.ErrorIs(t, err, model.ErrEmergencyActivationState)
+}
+
+func TestEmergencyAccessService_ActivationValidation(t *testing.T) {
+	f := newEmergencyAccessFixture(t)
+
+	tests := []struct {
+		name string
+		req  application.RequestEmergencyActivationRequest
+	}{
+		{"sem incidente", application.RequestEmergencyActivationRequest{
+			Justification: "Base de dados principal indisponível e sem acesso administrativo",
+		}},
+		{"justificação curta", application.RequestEmergencyActivationRequest{
+			IncidentRef:   "INC-1",
+			Justification: "urgente",
+		}},
+		{"duração excessiva", application.RequestEmergencyActivationRequest{
+			IncidentRef:   "INC-1",
+			Justification: "Base de dados principal indisponível e sem acesso administrativo",
+			Duration:      impl.DefaultMaxEmergencyActivationTime + time.Minute,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.TenantID = f.tenantID
+			req.AccountID = f.account.ID
+			req.RequestedBy = f.requester
+			_, err := f.service.RequestActivation(context.Background(), &req)
+			assert.ErrorIs(t, err, model.ErrInvalidEmergencyActivation)
+		})
+	}
+
+	_, err := f.service.ProvisionAccount(context.Background(), &application.ProvisionEmergencyAccountRequest{
+		TenantID: f.tenantID,
+		UserID:   f.userID,
+		Name:     "outra-conta",
+		ActorID:  f.requester,
+	})
+	assert.ErrorIs(t, err, model.ErrEmergencyAccountConflict)
+}
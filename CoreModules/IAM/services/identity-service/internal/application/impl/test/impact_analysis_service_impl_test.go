@@ -0,0 +1,232 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de análise de impacto (ImpactAnalysisService).
+ * Valida o cálculo de funções, usuários e escopos afetados por alterações propostas.
+ */
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeHierarchyRoleService é um RoleService em memória com a hierarquia usada nos testes
+// Apenas as operações de leitura usadas pela análise de impacto são implementadas
+type fakeHierarchyRoleService struct {
+	application.RoleService
+	roles       map[uuid.UUID]*model.Role
+	parents     map[uuid.UUID][]uuid.UUID
+	permissions map[uuid.UUID][]*model.Permission
+	users       map[uuid.UUID][]uuid.UUID
+}
+
+func (f *fakeHierarchyRoleService) GetRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error) {
+	role, ok := f.roles[roleID]
+	if !ok {
+		return nil, application.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (f *fakeHierarchyRoleService) GetRolePermissions(ctx context.Context, tenantID, roleID uuid.UUID, pagination application.Pagination) ([]*model.Permission, int64, error) {
+	perms := f.permissions[roleID]
+	return perms, int64(len(perms)), nil
+}
+
+func (f *fakeHierarchyRoleService) GetParentRoles(ctx context.Context, tenantID, roleID uuid.UUID, pagination application.Pagination) ([]*model.Role, int64, error) {
+	var parents []*model.Role
+	for _, id := range f.parents[roleID] {
+		parents = append(parents, f.roles[id])
+	}
+	return parents, int64(len(parents)), nil
+}
+
+func (f *fakeHierarchyRoleService) GetDescendantRoles(ctx context.Context, tenantID, roleID uuid.UUID) ([]*model.Role, error) {
+	var result []*model.Role
+	for childID, parents := range f.parents {
+		for _, p := range parents {
+			if p == roleID {
+				result = append(result, f.roles[childID])
+				sub, _ := f.GetDescendantRoles(ctx, tenantID, childID)
+				result = append(result, sub...)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeHierarchyRoleService) GetRoleUsers(ctx context.Context, tenantID, roleID uuid.UUID, activeOnly bool, pagination application.Pagination) ([]application.UserRoleDetail, int64, error) {
+	var result []application.UserRoleDetail
+	for userID, roleIDs := range f.users {
+		for _, id := range roleIDs {
+			if id == roleID {
+				result = append(result, application.UserRoleDetail{UserID: userID})
+			}
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (f *fakeHierarchyRoleService) GetUserActiveRoles(ctx context.Context, tenantID, userID uuid.UUID) ([]application.UserRoleAssignment, error) {
+	var result []application.UserRoleAssignment
+	for _, id := range f.users[userID] {
+		result = append(result, application.UserRoleAssignment{UserID: userID, Role: f.roles[id]})
+	}
+	return result, nil
+}
+
+// impactFixture contém os identificadores do cenário de teste
+type impactFixture struct {
+	service                          *fakeHierarchyRoleService
+	tenantID                         uuid.UUID
+	admin, manager, analyst, auditor uuid.UUID
+	reportsRead, usersManage         *model.Permission
+	u1, u2, u3, u4                   uuid.UUID
+}
+
+// newImpactFixture cria a hierarquia Admin -> Manager -> Analyst, e a função independente Auditor
+func newImpactFixture() *impactFixture {
+	f := &impactFixture{
+		tenantID: uuid.New(),
+		admin:    uuid.New(),
+		manager:  uuid.New(),
+		analyst:  uuid.New(),
+		auditor:  uuid.New(),
+		u1:       uuid.New(),
+		u2:       uuid.New(),
+		u3:       uuid.New(),
+		u4:       uuid.New(),
+	}
+
+	f.reportsRead = &model.Permission{ID: uuid.New(), Code: "reports:read", IsActive: true,
+		Metadata: map[string]interface{}{"api_scopes": []interface{}{"reports.read", "reports.export"}}}
+	f.usersManage = &model.Permission{ID: uuid.New(), Code: "users:manage", IsActive: true}
+
+	role := func(id uuid.UUID, code string) *model.Role {
+		return &model.Role{ID: id, TenantID: f.tenantID, Code: code, Name: code, IsActive: true}
+	}
+
+	f.service = &fakeHierarchyRoleService{
+		roles: map[uuid.UUID]*model.Role{
+			f.admin:   role(f.admin, "ADMIN"),
+			f.manager: role(f.manager, "MANAGER"),
+			f.analyst: role(f.analyst, "ANALYST"),
+			f.auditor: role(f.auditor, "AUDITOR"),
+		},
+		parents: map[uuid.UUID][]uuid.UUID{
+			f.manager: {f.admin},
+			f.analyst: {f.manager},
+		},
+		permissions: map[uuid.UUID][]*model.Permission{
+			f.admin:   {f.reportsRead, f.usersManage},
+			f.manager: {f.reportsRead},
+			f.auditor: {f.usersManage},
+		},
+		users: map[uuid.UUID][]uuid.UUID{
+			f.u1: {f.admin},
+			f.u2: {f.manager},
+			f.u3: {f.analyst},
+			f.u4: {f.analyst, f.auditor},
+		},
+	}
+
+	return f
+}
+
+// TestAnalyzeImpact_RevokeInheritedPermission verifica a propagação da revogação aos descendentes
+func TestAnalyzeImpact_RevokeInheritedPermission(t *testing.T) {
+	f := newImpactFixture()
+	service := impl.NewImpactAnalysisService(f.service)
+
+	report, err := service.AnalyzeImpact(context.Background(), application.ImpactAnalysisRequest{
+		TenantID:     f.tenantID,
+		RoleID:       f.admin,
+		Mutation:     application.MutationRevokePermission,
+		PermissionID: &f.usersManage.ID,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Summary.AffectedRoles)
+	assert.Equal(t, 3, report.Summary.AffectedUsers)
+	assert.Equal(t, 1, report.Summary.UsersRetainingAccess, "u4 mantém o acesso via AUDITOR")
+	require.Len(t, report.BrokenScopes, 1)
+	assert.Equal(t, "users:manage", report.BrokenScopes[0].Scope)
+	assert.Equal(t, 3, report.BrokenScopes[0].AffectedUsers)
+
+	for _, u := range report.AffectedUsers {
+		assert.NotEqual(t, f.u4, u.UserID)
+		assert.Equal(t, []string{"users:manage"}, u.LostPermissions)
+	}
+}
+
+// TestAnalyzeImpact_RevokePermissionGrantedElsewhere verifica que permissões diretas em descendentes são mantidas
+func TestAnalyzeImpact_RevokePermissionGrantedElsewhere(t *testing.T) {
+	f := newImpactFixture()
+	service := impl.NewImpactAnalysisService(f.service)
+
+	report, err := service.AnalyzeImpact(context.Background(), application.ImpactAnalysisRequest{
+		TenantID:     f.tenantID,
+		RoleID:       f.admin,
+		Mutation:     application.MutationRevokePermission,
+		PermissionID: &f.reportsRead.ID,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.AffectedRoles, 1)
+	assert.True(t, report.AffectedRoles[0].IsTarget)
+	require.Len(t, report.AffectedUsers, 1)
+	assert.Equal(t, f.u1, report.AffectedUsers[0].UserID)
+	assert.Equal(t, []string{"reports.export", "reports.read"}, report.AffectedUsers[0].LostScopes)
+}
+
+// TestAnalyzeImpact_RemoveChildRole verifica a perda de herança ao remover uma função filha
+func TestAnalyzeImpact_RemoveChildRole(t *testing.T) {
+	f := newImpactFixture()
+	service := impl.NewImpactAnalysisService(f.service)
+
+	report, err := service.AnalyzeImpact(context.Background(), application.ImpactAnalysisRequest{
+		TenantID:    f.tenantID,
+		RoleID:      f.admin,
+		Mutation:    application.MutationRemoveChildRole,
+		ChildRoleID: &f.manager,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Summary.AffectedRoles)
+	assert.Equal(t, 2, report.Summary.AffectedUsers)
+	for _, r := range report.AffectedRoles {
+		assert.Equal(t, []string{"users:manage"}, r.LostPermissions)
+	}
+}
+
+// TestAnalyzeImpact_InvalidRequests verifica a validação da alteração proposta
+func TestAnalyzeImpact_InvalidRequests(t *testing.T) {
+	f := newImpactFixture()
+	service := impl.NewImpactAnalysisService(f.service)
+
+	_, err := service.AnalyzeImpact(context.Background(), application.ImpactAnalysisRequest{
+		TenantID: f.tenantID,
+		RoleID:   f.admin,
+		Mutation: "RENAME_ROLE",
+	})
+	assert.ErrorIs(t, err, application.ErrInvalidMutation)
+
+	_, err = service.AnalyzeImpact(context.Background(), application.ImpactAnalysisRequest{
+		TenantID:     f.tenantID,
+		RoleID:       f.analyst,
+		Mutation:     application.MutationRevokePermission,
+		PermissionID: &f.usersManage.ID,
+	})
+	assert.ErrorIs(t, err, application.ErrPermissionNotAssigned)
+}
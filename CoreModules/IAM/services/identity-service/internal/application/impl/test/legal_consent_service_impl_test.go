@@ -0,0 +1,389 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a aceitação de documentos legais (LegalConsentService).
+ * Valida a publicação das versões, a aceitação apenas da versão em vigor com o hash publicado,
+ * o bloqueio do login pelos documentos obrigatórios e o relatório de aceitação.
+ */
+
+package test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeLegalConsentRepository é um LegalConsentRepository em memória
+type fakeLegalConsentRepository struct {
+	mu          sync.Mutex
+	documents   map[uuid.UUID]*model.LegalDocument
+	acceptances []*model.LegalAcceptance
+	activeUsers int
+	lastFilter  model.LegalAcceptanceFilter
+}
+
+func newFakeLegalConsentRepository() *fakeLegalConsentRepository {
+	return &fakeLegalConsentRepository{documents: make(map[uuid.UUID]*model.LegalDocument)}
+}
+
+func (r *fakeLegalConsentRepository) CreateDocument(ctx context.Context, document *model.LegalDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.documents {
+		if existing.TenantID == document.TenantID && existing.Type == document.Type && existing.Version == document.Version {
+			return model.ErrLegalDocumentVersionExists
+		}
+	}
+	copied := *document
+	r.documents[document.ID] = &copied
+	return nil
+}
+
+func (r *fakeLegalConsentRepository) GetDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	document, ok := r.documents[documentID]
+	if !ok || document.TenantID != tenantID {
+		return nil, model.ErrLegalDocumentNotFound
+	}
+	copied := *document
+	return &copied, nil
+}
+
+func (r *fakeLegalConsentRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID) ([]*model.LegalDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var documents []*model.LegalDocument
+	for _, document := range r.documents {
+		if document.TenantID == tenantID {
+			documents = append(documents, document)
+		}
+	}
+	return documents, nil
+}
+
+func (r *fakeLegalConsentRepository) ListCurrentDocuments(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]*model.LegalDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current(tenantID, now), nil
+}
+
+func (r *fakeLegalConsentRepository) ListUnacceptedDocuments(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.LegalDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var documents []*model.LegalDocument
+	for _, document := range r.current(tenantID, now) {
+		if !r.accepted(userID, document.ID) {
+			documents = append(documents, document)
+		}
+	}
+	return documents, nil
+}
+
+func (r *fakeLegalConsentRepository) SaveAcceptance(ctx context.Context, acceptance *model.LegalAcceptance) (*model.LegalAcceptance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.acceptances {
+		if existing.UserID == acceptance.UserID && existing.DocumentID == acceptance.DocumentID {
+			return existing, nil
+		}
+	}
+	r.acceptances = append(r.acceptances, acceptance)
+	return acceptance, nil
+}
+
+func (r *fakeLegalConsentRepository) ListAcceptances(ctx context.Context, tenantID uuid.UUID, filter model.LegalAcceptanceFilter) ([]*model.LegalAcceptance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastFilter = filter
+	var acceptances []*model.LegalAcceptance
+	for _, acceptance := range r.acceptances {
+		if acceptance.TenantID != tenantID || (filter.UserID != nil && acceptance.UserID != *filter.UserID) {
+			continue
+		}
+		acceptances = append(acceptances, acceptance)
+	}
+	return acceptances, nil
+}
+
+func (r *fakeLegalConsentRepository) CountAcceptances(ctx context.Context, tenantID, documentID uuid.UUID) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accepted := 0
+	for _, acceptance := range r.acceptances {
+		if acceptance.TenantID == tenantID && acceptance.DocumentID == documentID {
+			accepted++
+		}
+	}
+	return r.activeUsers, accepted, nil
+}
+
+// current retorna a versão em vigor de cada tipo de documento do tenant
+func (r *fakeLegalConsentRepository) current(tenantID uuid.UUID, now time.Time) []*model.LegalDocument {
+	latest := make(map[model.LegalDocumentType]*model.LegalDocument)
+	for _, document := range r.documents {
+		if document.TenantID != tenantID || !document.IsEffective(now) {
+			continue
+		}
+		if existing, ok := latest[document.Type]; !ok || document.EffectiveAt.After(existing.EffectiveAt) {
+			latest[document.Type] = document
+		}
+	}
+	documents := make([]*model.LegalDocument, 0, len(latest))
+	for _, document := range latest {
+		documents = append(documents, document)
+	}
+	sort.Slice(documents, func(i, j int) bool { return documents[i].Type < documents[j].Type })
+	return documents
+}
+
+func (r *fakeLegalConsentRepository) accepted(userID, documentID uuid.UUID) bool {
+	for _, acceptance := range r.acceptances {
+		if acceptance.UserID == userID && acceptance.DocumentID == documentID {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeLegalAcceptanceGate retorna sempre os documentos indicados como pendentes
+type fakeLegalAcceptanceGate struct {
+	pending []*model.LegalDocument
+}
+
+func (g *fakeLegalAcceptanceGate) LoginPendingDocuments(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LegalDocument, error) {
+	return g.pending, nil
+}
+
+type legalConsentFixture struct {
+	repo     *fakeLegalConsentRepository
+	service  application.LegalConsentService
+	tenantID uuid.UUID
+	userID   uuid.UUID
+}
+
+func newLegalConsentFixture(config impl.LegalConsentConfig) *legalConsentFixture {
+	f := &legalConsentFixture{
+		repo:     newFakeLegalConsentRepository(),
+		tenantID: uuid.New(),
+		userID:   uuid.New(),
+	}
+	f.service = impl.NewLegalConsentService(f.repo, config)
+	return f
+}
+
+// publish publica uma versão do documento com o conteúdo indicado
+func (f *legalConsentFixture) publish(t *testing.T, documentType model.LegalDocumentType, version, content string, required bool, effectiveAt *time.Time) *model.LegalDocument {
+	document, err := f.service.PublishDocument(context.Background(), &application.PublishLegalDocumentRequest{
+		TenantID:    f.tenantID,
+		Type:        documentType,
+		Version:     version,
+		Title:       "Termos de Serviço " + version,
+		URL:         "https://innovabiz.test/legal/" + string(documentType) + "/" + version,
+		ContentHash: model.LegalDocumentHash([]byte(content)),
+		Required:    required,
+		EffectiveAt: effectiveAt,
+		PublishedBy: uuid.New(),
+	})
+	require.NoError(t, err)
+	return document
+}
+
+func (f *legalConsentFixture) accept(document *model.LegalDocument, hash string) (*model.LegalAcceptance, error) {
+	return f.service.Accept(context.Background(), &application.AcceptLegalDocumentRequest{
+		TenantID:     f.tenantID,
+		UserID:       f.userID,
+		DocumentID:   document.ID,
+		DocumentHash: hash,
+		IPAddress:    "198.51.100.7",
+		UserAgent:    "Mozilla/5.0",
+	})
+}
+
+func TestLegalConsentService_PublishDocument(t *testing.T) {
+	f := newLegalConsentFixture(impl.DefaultLegalConsentConfig())
+	ctx := context.Background()
+
+	document := f.publish(t, model.LegalDocumentTermsOfService, "1.0", "termos v1", true, nil)
+	assert.True(t, document.IsEffective(time.Now()))
+	assert.Equal(t, model.LegalDocumentHash([]byte("termos v1")), document.ContentHash)
+
+	valid := application.PublishLegalDocumentRequest{
+		TenantID:    f.tenantID,
+		Type:        model.LegalDocumentPrivacyPolicy,
+		Version:     "2025-01",
+		Title:       "Política de Privacidade",
+		URL:         "https://innovabiz.test/legal/privacy",
+		ContentHash: model.LegalDocumentHash([]byte("privacidade")),
+	}
+	tests := []struct {
+		name   string
+		mutate func(*application.PublishLegalDocumentRequest)
+		want   error
+	}{
+		{"tipo desconhecido", func(r *application.PublishLegalDocumentRequest) { r.Type = "cookies" }, model.ErrInvalidLegalDocument},
+		{"sem versão", func(r *application.PublishLegalDocumentRequest) { r.Version = " " }, model.ErrInvalidLegalDocument},
+		{"hash inválido", func(r *application.PublishLegalDocumentRequest) { r.ContentHash = "abc" }, model.ErrInvalidLegalDocument},
+		{"URL sem esquema", func(r *application.PublishLegalDocumentRequest) { r.URL = "innovabiz.test/legal" }, model.ErrInvalidLegalDocument},
+		{"sem tenant", func(r *application.PublishLegalDocumentRequest) { r.TenantID = uuid.Nil }, model.ErrInvalidTenantID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.mutate(&req)
+			_, err := f.service.PublishDocument(ctx, &req)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	t.Run("versão repetida", func(t *testing.T) {
+		_, err := f.service.PublishDocument(ctx, &application.PublishLegalDocumentRequest{
+			TenantID:    f.tenantID,
+			Type:        model.LegalDocumentTermsOfService,
+			Version:     "1.0",
+			Title:       "Termos de Serviço",
+			URL:         "https://innovabiz.test/legal/terms",
+			ContentHash: model.LegalDocumentHash([]byte("outro conteúdo")),
+		})
+		assert.ErrorIs(t, err, application.ErrLegalDocumentVersionExists)
+	})
+}
+
+func TestLegalConsentService_Accept(t *testing.T) {
+	f := newLegalConsentFixture(impl.DefaultLegalConsentConfig())
+	past := time.Now().Add(-time.Hour)
+	v1 := f.publish(t, model.LegalDocumentTermsOfService, "1.0", "termos v1", true, &past)
+
+	t.Run("hash diferente do publicado", func(t *testing.T) {
+		_, err := f.accept(v1, model.LegalDocumentHash([]byte("termos adulterados")))
+		assert.ErrorIs(t, err, application.ErrLegalDocumentHashMismatch)
+	})
+
+	acceptance, err := f.accept(v1, v1.ContentHash)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", acceptance.Version)
+	assert.Equal(t, v1.ContentHash, acceptance.DocumentHash)
+	assert.Equal(t, "198.51.100.7", acceptance.IPAddress)
+
+	t.Run("aceitar de novo retorna o registo original", func(t *testing.T) {
+		again, err := f.accept(v1, v1.ContentHash)
+		require.NoError(t, err)
+		assert.Equal(t, acceptance.ID, again.ID)
+		assert.Len(t, f.repo.acceptances, 1)
+	})
+
+	t.Run("versão ainda não em vigor", func(t *testing.T) {
+		future := time.Now().Add(24 * time.Hour)
+		v2 := f.publish(t, model.LegalDocumentTermsOfService, "2.0", "termos v2", true, &future)
+		_, err := f.accept(v2, v2.ContentHash)
+		assert.ErrorIs(t, err, application.ErrLegalDocumentNotCurrent)
+	})
+
+	t.Run("versão substituída", func(t *testing.T) {
+		v3 := f.publish(t, model.LegalDocumentTermsOfService, "3.0", "termos v3", true, nil)
+		_, err := f.accept(v1, v1.ContentHash)
+		assert.ErrorIs(t, err, application.ErrLegalDocumentNotCurrent)
+
+		pending, err := f.service.ListPendingDocuments(context.Background(), f.tenantID, f.userID)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, v3.ID, pending[0].ID, "a nova versão tem de ser aceite outra vez")
+	})
+
+	t.Run("documento de outro tenant", func(t *testing.T) {
+		_, err := f.service.Accept(context.Background(), &application.AcceptLegalDocumentRequest{
+			TenantID: uuid.New(), UserID: f.userID, DocumentID: v1.ID, DocumentHash: v1.ContentHash,
+		})
+		assert.ErrorIs(t, err, application.ErrLegalDocumentNotFound)
+	})
+}
+
+func TestLegalConsentService_LoginPendingDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("apenas os documentos obrigatórios bloqueiam o login", func(t *testing.T) {
+		f := newLegalConsentFixture(impl.DefaultLegalConsentConfig())
+		terms := f.publish(t, model.LegalDocumentTermsOfService, "1.0", "termos", true, nil)
+		f.publish(t, model.LegalDocumentConsent, "1.0", "marketing", false, nil)
+
+		pending, err := f.service.LoginPendingDocuments(ctx, f.tenantID, f.userID)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, terms.ID, pending[0].ID)
+
+		_, err = f.accept(terms, terms.ContentHash)
+		require.NoError(t, err)
+		pending, err = f.service.LoginPendingDocuments(ctx, f.tenantID, f.userID)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		all, err := f.service.ListPendingDocuments(ctx, f.tenantID, f.userID)
+		require.NoError(t, err)
+		assert.Len(t, all, 1, "o consentimento opcional continua por aceitar")
+	})
+
+	t.Run("bloqueio desativado", func(t *testing.T) {
+		f := newLegalConsentFixture(impl.LegalConsentConfig{BlockLogin: false})
+		f.publish(t, model.LegalDocumentTermsOfService, "1.0", "termos", true, nil)
+
+		pending, err := f.service.LoginPendingDocuments(ctx, f.tenantID, f.userID)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
+
+func TestLegalConsentService_ListAcceptances(t *testing.T) {
+	f := newLegalConsentFixture(impl.DefaultLegalConsentConfig())
+	ctx := context.Background()
+
+	acceptances, err := f.service.ListAcceptances(ctx, f.tenantID, model.LegalAcceptanceFilter{})
+	require.NoError(t, err)
+	assert.NotNil(t, acceptances)
+	assert.Equal(t, impl.DefaultLegalAcceptancesLimit, f.repo.lastFilter.Limit)
+
+	_, err = f.service.ListAcceptances(ctx, f.tenantID, model.LegalAcceptanceFilter{Limit: 50000})
+	require.NoError(t, err)
+	assert.Equal(t, impl.MaxLegalAcceptancesLimit, f.repo.lastFilter.Limit)
+
+	_, err = f.service.ListAcceptances(ctx, f.tenantID, model.LegalAcceptanceFilter{Limit: -1})
+	assert.ErrorIs(t, err, application.ErrInvalidLegalAcceptanceFilter)
+}
+
+func TestLegalConsentService_AcceptanceReport(t *testing.T) {
+	f := newLegalConsentFixture(impl.DefaultLegalConsentConfig())
+	f.repo.activeUsers = 4
+	terms := f.publish(t, model.LegalDocumentTermsOfService, "1.0", "termos", true, nil)
+
+	_, err := f.accept(terms, terms.ContentHash)
+	require.NoError(t, err)
+
+	report, err := f.service.AcceptanceReport(context.Background(), f.tenantID, terms.ID)
+	require.NoError(t, err)
+	assert.Equal(t, terms.ID, report.Document.ID)
+	assert.Equal(t, 4, report.ActiveUsers)
+	assert.Equal(t, 1, report.Accepted)
+	assert.Equal(t, 3, report.Pending)
+	assert.InDelta(t, 0.25, report.AcceptanceRate, 1e-9)
+
+	_, err = f.service.AcceptanceReport(context.Background(), f.tenantID, uuid.New())
+	assert.ErrorIs(t, err, application.ErrLegalDocumentNotFound)
+}
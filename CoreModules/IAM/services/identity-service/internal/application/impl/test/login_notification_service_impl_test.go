@@ -0,0 +1,646 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as notificações de logins suspeitos (LoginNotificationService).
+ * Valida a deteção dos dispositivos e das localizações desconhecidas, a escolha dos modelos
+ * por mercado e idioma, as entregas por canal e a resposta "não fui eu".
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeLoginNotificationRepository é um LoginNotificationRepository em memória
+type fakeLoginNotificationRepository struct {
+	mu            sync.Mutex
+	settings      map[uuid.UUID]*model.LoginNotificationSettings
+	templates     map[uuid.UUID]*model.LoginNotificationTemplate
+	recipients    map[uuid.UUID]*model.LoginNotificationRecipient
+	contexts      map[uuid.UUID]map[string]time.Time
+	notifications map[uuid.UUID]*model.LoginNotification
+	resets        []*model.CredentialResetToken
+	sessions      map[uuid.UUID]int
+}
+
+func newFakeLoginNotificationRepository() *fakeLoginNotificationRepository {
+	return &fakeLoginNotificationRepository{
+		settings:      make(map[uuid.UUID]*model.LoginNotificationSettings),
+		templates:     make(map[uuid.UUID]*model.LoginNotificationTemplate),
+		recipients:    make(map[uuid.UUID]*model.LoginNotificationRecipient),
+		contexts:      make(map[uuid.UUID]map[string]time.Time),
+		notifications: make(map[uuid.UUID]*model.LoginNotification),
+		sessions:      make(map[uuid.UUID]int),
+	}
+}
+
+func (r *fakeLoginNotificationRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.LoginNotificationSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, ok := r.settings[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *settings
+	return &copied, nil
+}
+
+func (r *fakeLoginNotificationRepository) SaveSettings(ctx context.Context, settings *model.LoginNotificationSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *settings
+	r.settings[settings.TenantID] = &copied
+	return nil
+}
+
+func (r *fakeLoginNotificationRepository) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]*model.LoginNotificationTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var templates []*model.LoginNotificationTemplate
+	for _, template := range r.templates {
+		if template.TenantID == tenantID {
+			copied := *template
+			templates = append(templates, &copied)
+		}
+	}
+	return templates, nil
+}
+
+// SaveTemplate simula a substituição do modelo da mesma mensagem, canal, mercado e idioma
+func (r *fakeLoginNotificationRepository) SaveTemplate(ctx context.Context, template *model.LoginNotificationTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.templates {
+		if existing.TenantID == template.TenantID && existing.Kind == template.Kind && existing.Channel == template.Channel &&
+			existing.Market == template.Market && existing.Locale == template.Locale {
+			template.ID = id
+		}
+	}
+	copied := *template
+	r.templates[template.ID] = &copied
+	return nil
+}
+
+func (r *fakeLoginNotificationRepository) DeleteTemplate(ctx context.Context, tenantID, templateID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || template.TenantID != tenantID {
+		return model.ErrLoginNotificationTemplateNotFound
+	}
+	delete(r.templates, templateID)
+	return nil
+}
+
+func (r *fakeLoginNotificationRepository) GetRecipient(ctx context.Context, tenantID, userID uuid.UUID) (*model.LoginNotificationRecipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recipient, ok := r.recipients[userID]
+	if !ok || recipient.TenantID != tenantID {
+		return nil, model.ErrUserNotFound
+	}
+	copied := *recipient
+	return &copied, nil
+}
+
+func (r *fakeLoginNotificationRepository) TouchLoginContexts(ctx context.Context, tenantID, userID uuid.UUID, contexts []model.LoginContextFingerprint, seenAt, staleBefore time.Time) ([]model.LoginContextKind, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	known, ok := r.contexts[userID]
+	if !ok {
+		known = make(map[string]time.Time)
+		r.contexts[userID] = known
+	}
+	for fingerprint, lastSeen := range known {
+		if lastSeen.Before(staleBefore) {
+			delete(known, fingerprint)
+		}
+	}
+	firstLogin := len(known) == 0
+
+	var unknown []model.LoginContextKind
+	for _, context := range contexts {
+		if _, ok := known[context.Fingerprint]; !ok {
+			unknown = append(unknown, context.Kind)
+		}
+		known[context.Fingerprint] = seenAt
+	}
+	return unknown, firstLogin, nil
+}
+
+func (r *fakeLoginNotificationRepository) CreateNotification(ctx context.Context, notification *model.LoginNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *notification
+	r.notifications[notification.ID] = &copied
+	return nil
+}
+
+func (r *fakeLoginNotificationRepository) SaveDeliveries(ctx context.Context, tenantID, notificationID uuid.UUID, deliveries []model.LoginNotificationDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, ok := r.notifications[notificationID]
+	if !ok || notification.TenantID != tenantID {
+		return model.ErrLoginNotificationNotFound
+	}
+	notification.Deliveries = deliveries
+	return nil
+}
+
+func (r *fakeLoginNotificationRepository) GetNotification(ctx context.Context, tenantID, notificationID uuid.UUID) (*model.LoginNotification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification, ok := r.notifications[notificationID]
+	if !ok || notification.TenantID != tenantID {
+		return nil, model.ErrLoginNotificationNotFound
+	}
+	copied := *notification
+	return &copied, nil
+}
+
+func (r *fakeLoginNotificationRepository) ListNotifications(ctx context.Context, tenantID, userID uuid.UUID, limit int) ([]*model.LoginNotification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var notifications []*model.LoginNotification
+	for _, notification := range r.notifications {
+		if notification.TenantID == tenantID && notification.UserID == userID && len(notifications) < limit {
+			copied := *notification
+			notifications = append(notifications, &copied)
+		}
+	}
+	return notifications, nil
+}
+
+// RecordNotMeResponse simula a resposta atómica: termina as sessões e esquece os contextos do login
+func (r *fakeLoginNotificationRepository) RecordNotMeResponse(ctx context.Context, notification *model.LoginNotification, reset *model.CredentialResetToken, respondedAt time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.notifications[notification.ID]
+	if !ok || stored.RespondedAt != nil || !respondedAt.Before(stored.ExpiresAt) {
+		return 0, model.ErrInvalidLoginResponseToken
+	}
+	ended := r.sessions[notification.UserID]
+	r.sessions[notification.UserID] = 0
+	stored.RespondedAt = &respondedAt
+	stored.SessionsEnded = ended
+	r.resets = append(r.resets, reset)
+	for _, context := range notification.Contexts {
+		delete(r.contexts[notification.UserID], context.Fingerprint)
+	}
+	return ended, nil
+}
+
+func (r *fakeLoginNotificationRepository) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, notification := range r.notifications {
+		notification.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+}
+
+// fakeLoginNotificationSender regista as mensagens entregues num canal
+type fakeLoginNotificationSender struct {
+	mu       sync.Mutex
+	messages []*application.LoginNotificationMessage
+	err      error
+}
+
+func (s *fakeLoginNotificationSender) Send(ctx context.Context, message *application.LoginNotificationMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	copied := *message
+	s.messages = append(s.messages, &copied)
+	return nil
+}
+
+func (s *fakeLoginNotificationSender) sent() []*application.LoginNotificationMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*application.LoginNotificationMessage(nil), s.messages...)
+}
+
+func (s *fakeLoginNotificationSender) last(t *testing.T) *application.LoginNotificationMessage {
+	messages := s.sent()
+	require.NotEmpty(t, messages, "nenhuma mensagem entregue")
+	return messages[len(messages)-1]
+}
+
+var loginNotificationTokenPattern = regexp.MustCompile(`token=([^\s"]+)`)
+
+// tokenFrom extrai o token do link contido na mensagem
+func tokenFrom(t *testing.T, message *application.LoginNotificationMessage) string {
+	match := loginNotificationTokenPattern.FindStringSubmatch(message.Body)
+	require.Len(t, match, 2, "link sem token: %s", message.Body)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+type loginNotificationFixture struct {
+	repo      *fakeLoginNotificationRepository
+	email     *fakeLoginNotificationSender
+	sms       *fakeLoginNotificationSender
+	service   application.LoginNotificationService
+	tenantID  uuid.UUID
+	recipient *model.LoginNotificationRecipient
+}
+
+func newLoginNotificationFixture(t *testing.T) *loginNotificationFixture {
+	config := impl.DefaultLoginNotificationConfig()
+	config.SigningKey = []byte("chave-de-teste-com-pelo-menos-32-bytes!!")
+	config.ResponseURL = "https://app.innovabiz.test/auth/not-me"
+	config.CredentialResetURL = "https://app.innovabiz.test/auth/reset"
+
+	f := &loginNotificationFixture{
+		repo:     newFakeLoginNotificationRepository(),
+		email:    &fakeLoginNotificationSender{},
+		sms:      &fakeLoginNotificationSender{},
+		tenantID: uuid.New(),
+	}
+	f.service = impl.NewLoginNotificationService(f.repo, map[model.LoginNotificationChannel]application.LoginNotificationSender{
+		model.LoginNotificationChannelEmail: f.email,
+		model.LoginNotificationChannelSMS:   f.sms,
+	}, config)
+
+	f.recipient = &model.LoginNotificationRecipient{
+		UserID:      uuid.New(),
+		TenantID:    f.tenantID,
+		Email:       "ana@innovabiz.test",
+		DisplayName: "Ana Silva",
+		Timezone:    "Africa/Luanda",
+		Status:      model.UserStatusActive,
+	}
+	f.repo.recipients[f.recipient.UserID] = f.recipient
+	return f
+}
+
+func (f *loginNotificationFixture) enable(t *testing.T, channels ...model.LoginNotificationChannel) {
+	_, err := f.service.UpdateSettings(context.Background(), &application.UpdateLoginNotificationSettingsRequest{
+		TenantID:  f.tenantID,
+		Enabled:   true,
+		Channels:  channels,
+		Market:    "angola",
+		UpdatedBy: uuid.New(),
+	})
+	require.NoError(t, err)
+}
+
+func (f *loginNotificationFixture) login(t *testing.T, deviceID, ipAddress, country string) {
+	err := f.service.ObserveLogin(context.Background(), &model.LoginEvent{
+		TenantID:   f.tenantID,
+		UserID:     f.recipient.UserID,
+		Method:     "magic_link",
+		DeviceID:   deviceID,
+		IPAddress:  ipAddress,
+		UserAgent:  "Mozilla/5.0 (Windows NT 10.0)",
+		Country:    country,
+		OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+}
+
+func TestLoginNotificationService_Settings(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	ctx := context.Background()
+
+	settings, err := f.service.GetSettings(ctx, f.tenantID)
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled, "as notificações devem estar desativadas por padrão")
+
+	t.Run("sem canais", func(t *testing.T) {
+		_, err := f.service.UpdateSettings(ctx, &application.UpdateLoginNotificationSettingsRequest{TenantID: f.tenantID, Enabled: true})
+		assert.ErrorIs(t, err, application.ErrInvalidLoginNotificationSettings)
+	})
+
+	t.Run("canal desconhecido", func(t *testing.T) {
+		_, err := f.service.UpdateSettings(ctx, &application.UpdateLoginNotificationSettingsRequest{
+			TenantID: f.tenantID,
+			Enabled:  true,
+			Channels: []model.LoginNotificationChannel{"fax"},
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidLoginNotificationSettings)
+	})
+
+	t.Run("idioma inválido", func(t *testing.T) {
+		_, err := f.service.UpdateSettings(ctx, &application.UpdateLoginNotificationSettingsRequest{
+			TenantID:      f.tenantID,
+			Enabled:       true,
+			Channels:      []model.LoginNotificationChannel{model.LoginNotificationChannelEmail},
+			DefaultLocale: "pt_1",
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidLoginNotificationSettings)
+	})
+
+	settings, err = f.service.UpdateSettings(ctx, &application.UpdateLoginNotificationSettingsRequest{
+		TenantID:      f.tenantID,
+		Enabled:       true,
+		Channels:      []model.LoginNotificationChannel{model.LoginNotificationChannelEmail},
+		Market:        " Brazil ",
+		DefaultLocale: "pt_br",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "brazil", settings.Market)
+	assert.Equal(t, "pt-BR", settings.DefaultLocale)
+}
+
+func TestLoginNotificationService_NewContexts(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	f.enable(t, model.LoginNotificationChannelEmail)
+
+	// O primeiro login apenas regista os contextos do usuário
+	f.login(t, "device-1", "197.149.90.10", "AO")
+	assert.Empty(t, f.email.sent())
+
+	t.Run("contexto conhecido", func(t *testing.T) {
+		f.login(t, "device-1", "197.149.90.22", "AO")
+		assert.Empty(t, f.email.sent())
+	})
+
+	t.Run("novo dispositivo", func(t *testing.T) {
+		f.login(t, "device-2", "197.149.90.10", "AO")
+
+		message := f.email.last(t)
+		assert.Equal(t, f.recipient.Email, message.To)
+		assert.Equal(t, model.LoginNotificationKindAlert, message.Kind)
+		assert.Equal(t, "pt-PT", message.Locale, "o mercado angolano usa o português europeu")
+		assert.NotEmpty(t, message.Subject)
+		assert.Contains(t, message.Body, "Ana Silva")
+		assert.Contains(t, message.Body, "https://app.innovabiz.test/auth/not-me?token=")
+
+		notifications, err := f.service.ListNotifications(context.Background(), f.tenantID, f.recipient.UserID)
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, []model.LoginNotificationReason{model.LoginNotificationReasonNewDevice}, notifications[0].Reasons)
+		require.Len(t, notifications[0].Deliveries, 1)
+		assert.Equal(t, model.LoginDeliverySent, notifications[0].Deliveries[0].Status)
+	})
+
+	t.Run("nova localização", func(t *testing.T) {
+		f.login(t, "device-1", "41.220.10.5", "MZ")
+
+		notifications, err := f.service.ListNotifications(context.Background(), f.tenantID, f.recipient.UserID)
+		require.NoError(t, err)
+		assert.Len(t, notifications, 2)
+		assert.Len(t, f.email.sent(), 2)
+	})
+}
+
+func TestLoginNotificationService_FirstLogin(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	_, err := f.service.UpdateSettings(context.Background(), &application.UpdateLoginNotificationSettingsRequest{
+		TenantID:         f.tenantID,
+		Enabled:          true,
+		Channels:         []model.LoginNotificationChannel{model.LoginNotificationChannelEmail},
+		NotifyFirstLogin: true,
+	})
+	require.NoError(t, err)
+
+	f.login(t, "device-1", "197.149.90.10", "AO")
+	message := f.email.last(t)
+	assert.Equal(t, "en", message.Locale)
+}
+
+func TestLoginNotificationService_Disabled(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+
+	f.login(t, "device-1", "197.149.90.10", "AO")
+	f.login(t, "device-2", "197.149.90.10", "AO")
+	assert.Empty(t, f.email.sent())
+
+	// Os contextos registados com as notificações desativadas continuam conhecidos depois da ativação
+	f.enable(t, model.LoginNotificationChannelEmail)
+	f.login(t, "device-2", "197.149.90.10", "AO")
+	assert.Empty(t, f.email.sent())
+
+	f.login(t, "device-3", "197.149.90.10", "AO")
+	assert.Len(t, f.email.sent(), 1)
+}
+
+func TestLoginNotificationService_Deliveries(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	f.enable(t, model.LoginNotificationChannelSMS, model.LoginNotificationChannelEmail, model.LoginNotificationChannelPush)
+	f.login(t, "device-1", "197.149.90.10", "AO")
+
+	t.Run("sem telefone nem remetente", func(t *testing.T) {
+		f.login(t, "device-2", "197.149.90.10", "AO")
+
+		notifications, err := f.service.ListNotifications(context.Background(), f.tenantID, f.recipient.UserID)
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		statuses := make(map[model.LoginNotificationChannel]string)
+		for _, delivery := range notifications[0].Deliveries {
+			statuses[delivery.Channel] = delivery.Status
+		}
+		assert.Equal(t, map[model.LoginNotificationChannel]string{
+			model.LoginNotificationChannelSMS:   model.LoginDeliverySkipped,
+			model.LoginNotificationChannelEmail: model.LoginDeliverySent,
+			model.LoginNotificationChannelPush:  model.LoginDeliverySkipped,
+		}, statuses)
+	})
+
+	t.Run("falha de um canal", func(t *testing.T) {
+		f.recipient.PhoneNumber = "+244923000000"
+		f.sms.err = errors.New("gateway indisponível")
+		f.login(t, "device-3", "197.149.90.10", "AO")
+
+		message := f.email.last(t)
+		notificationID := message.NotificationID
+		notification, err := f.repo.GetNotification(context.Background(), f.tenantID, notificationID)
+		require.NoError(t, err)
+		for _, delivery := range notification.Deliveries {
+			switch delivery.Channel {
+			case model.LoginNotificationChannelSMS:
+				assert.Equal(t, model.LoginDeliveryFailed, delivery.Status)
+				assert.Contains(t, delivery.Error, "gateway indisponível")
+			case model.LoginNotificationChannelEmail:
+				assert.Equal(t, model.LoginDeliverySent, delivery.Status)
+			}
+		}
+	})
+}
+
+func TestLoginNotificationService_Templates(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	f.enable(t, model.LoginNotificationChannelEmail)
+	ctx := context.Background()
+
+	t.Run("campo desconhecido", func(t *testing.T) {
+		_, err := f.service.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+			TenantID: f.tenantID,
+			Kind:     model.LoginNotificationKindAlert,
+			Channel:  model.LoginNotificationChannelEmail,
+			Locale:   "pt-PT",
+			Subject:  "Novo acesso",
+			Body:     "Olá {{.Nome}}",
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidLoginNotificationTemplate)
+	})
+
+	t.Run("assunto obrigatório no email", func(t *testing.T) {
+		_, err := f.service.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+			TenantID: f.tenantID,
+			Kind:     model.LoginNotificationKindAlert,
+			Channel:  model.LoginNotificationChannelEmail,
+			Locale:   "pt-PT",
+			Body:     "Olá {{.Name}}",
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidLoginNotificationTemplate)
+	})
+
+	general, err := f.service.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+		TenantID: f.tenantID,
+		Kind:     model.LoginNotificationKindAlert,
+		Channel:  model.LoginNotificationChannelEmail,
+		Locale:   "pt",
+		Subject:  "Acesso geral",
+		Body:     "Geral {{.Name}} {{.ResponseURL}}",
+	})
+	require.NoError(t, err)
+	market, err := f.service.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+		TenantID: f.tenantID,
+		Kind:     model.LoginNotificationKindAlert,
+		Channel:  model.LoginNotificationChannelEmail,
+		Market:   "Angola",
+		Locale:   "pt-PT",
+		Subject:  "Acesso em Angola",
+		Body:     "Angola {{.Name}} {{.ResponseURL}}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "angola", market.Market)
+
+	// O modelo do mercado prevalece sobre o modelo geral
+	f.login(t, "device-1", "197.149.90.10", "AO")
+	f.login(t, "device-2", "197.149.90.10", "AO")
+	message := f.email.last(t)
+	assert.Equal(t, "Acesso em Angola", message.Subject)
+	assert.True(t, strings.HasPrefix(message.Body, "Angola Ana Silva https://app.innovabiz.test/auth/not-me?token="))
+
+	t.Run("substituição", func(t *testing.T) {
+		replaced, err := f.service.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+			TenantID: f.tenantID,
+			Kind:     model.LoginNotificationKindAlert,
+			Channel:  model.LoginNotificationChannelEmail,
+			Market:   "angola",
+			Locale:   "pt-PT",
+			Subject:  "Acesso em Angola (v2)",
+			Body:     "Angola v2 {{.ResponseURL}}",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, market.ID, replaced.ID)
+
+		templates, err := f.service.ListTemplates(ctx, f.tenantID)
+		require.NoError(t, err)
+		assert.Len(t, templates, 2)
+	})
+
+	// Sem o modelo do mercado, é usado o modelo geral da mesma língua
+	require.NoError(t, f.service.DeleteTemplate(ctx, f.tenantID, market.ID, uuid.New()))
+	f.login(t, "device-3", "197.149.90.10", "AO")
+	assert.Equal(t, "Acesso geral", f.email.last(t).Subject)
+
+	// Sem modelos do tenant, é usado o modelo padrão
+	require.NoError(t, f.service.DeleteTemplate(ctx, f.tenantID, general.ID, uuid.New()))
+	f.login(t, "device-4", "197.149.90.10", "AO")
+	builtin := model.BuiltinLoginNotificationTemplate(model.LoginNotificationKindAlert, model.LoginNotificationChannelEmail, "pt-PT")
+	assert.Equal(t, builtin.Subject, f.email.last(t).Subject)
+
+	err = f.service.DeleteTemplate(ctx, f.tenantID, general.ID, uuid.New())
+	assert.ErrorIs(t, err, application.ErrLoginNotificationTemplateNotFound)
+}
+
+func TestLoginNotificationService_RespondNotMe(t *testing.T) {
+	f := newLoginNotificationFixture(t)
+	f.enable(t, model.LoginNotificationChannelEmail)
+	ctx := context.Background()
+
+	f.login(t, "device-1", "197.149.90.10", "AO")
+	f.login(t, "device-2", "102.132.40.1", "NG")
+	alert := f.email.last(t)
+	token := tokenFrom(t, alert)
+	f.repo.sessions[f.recipient.UserID] = 3
+
+	t.Run("token forjado", func(t *testing.T) {
+		forged := token[:strings.Index(token, ".")+1] + "segredo-errado"
+		_, err := f.service.RespondNotMe(ctx, f.tenantID, forged, "197.149.90.10")
+		assert.ErrorIs(t, err, application.ErrInvalidLoginResponseToken)
+	})
+
+	t.Run("token mal formado", func(t *testing.T) {
+		_, err := f.service.RespondNotMe(ctx, f.tenantID, "token", "197.149.90.10")
+		assert.ErrorIs(t, err, application.ErrInvalidLoginResponseToken)
+	})
+
+	t.Run("outro tenant", func(t *testing.T) {
+		_, err := f.service.RespondNotMe(ctx, uuid.New(), token, "197.149.90.10")
+		assert.ErrorIs(t, err, application.ErrInvalidLoginResponseToken)
+	})
+
+	result, err := f.service.RespondNotMe(ctx, f.tenantID, token, "197.149.90.10")
+	require.NoError(t, err)
+	assert.Equal(t, alert.NotificationID, result.NotificationID)
+	assert.Equal(t, 3, result.SessionsEnded)
+	assert.WithinDuration(t, time.Now().Add(impl.DefaultLoginNotificationCredentialResetTTL), result.CredentialResetExpiresAt, time.Minute)
+
+	// O usuário recebe o link de redefinição; o repositório guarda apenas o resumo do token
+	reset := f.email.last(t)
+	assert.Equal(t, model.LoginNotificationKindCredentialReset, reset.Kind)
+	assert.Contains(t, reset.Body, "https://app.innovabiz.test/auth/reset?token=")
+	require.Len(t, f.repo.resets, 1)
+	assert.Equal(t, f.recipient.UserID, f.repo.resets[0].UserID)
+	assert.NotEqual(t, tokenFrom(t, reset), f.repo.resets[0].TokenHash)
+	assert.Len(t, f.repo.resets[0].TokenHash, 64)
+
+	t.Run("uso único", func(t *testing.T) {
+		_, err := f.service.RespondNotMe(ctx, f.tenantID, token, "197.149.90.10")
+		assert.ErrorIs(t, err, application.ErrInvalidLoginResponseToken)
+	})
+
+	t.Run("contextos esquecidos", func(t *testing.T) {
+		before := len(f.email.sent())
+		f.login(t, "device-2", "102.132.40.1", "NG")
+		assert.Len(t, f.email.sent(), before+1, "o login recusado pelo usuário volta a ser notificado")
+	})
+
+	t.Run("link expirado", func(t *testing.T) {
+		f.login(t, "device-5", "102.132.40.1", "NG")
+		expired := tokenFrom(t, f.email.last(t))
+		f.repo.expire()
+
+		_, err := f.service.RespondNotMe(ctx, f.tenantID, expired, "197.149.90.10")
+		assert.ErrorIs(t, err, application.ErrInvalidLoginResponseToken)
+	})
+}
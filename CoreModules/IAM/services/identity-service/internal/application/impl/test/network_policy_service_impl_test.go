@@ -0,0 +1,417 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as políticas de rede por tenant (NetworkPolicyService).
+ * Valida a precedência das recusas, as listas de permitidos da API de administração e das
+ * funções, a invalidação do cache e a auditoria obrigatória dos acessos de emergência.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeNetworkPolicyRepository é um NetworkPolicyRepository em memória
+type fakeNetworkPolicyRepository struct {
+	mu        sync.Mutex
+	policies  map[uuid.UUID]*model.NetworkPolicy
+	overrides map[uuid.UUID]*model.BreakGlassOverride
+	uses      []*model.BreakGlassUse
+	loads     int
+	useErr    error
+}
+
+func newFakeNetworkPolicyRepository() *fakeNetworkPolicyRepository {
+	return &fakeNetworkPolicyRepository{
+		policies:  make(map[uuid.UUID]*model.NetworkPolicy),
+		overrides: make(map[uuid.UUID]*model.BreakGlassOverride),
+	}
+}
+
+func (r *fakeNetworkPolicyRepository) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.NetworkPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loads++
+	var policies []*model.NetworkPolicy
+	for _, policy := range r.policies {
+		if policy.TenantID == tenantID {
+			copied := *policy
+			copied.CIDRs = append([]string{}, policy.CIDRs...)
+			policies = append(policies, &copied)
+		}
+	}
+	return policies, nil
+}
+
+func (r *fakeNetworkPolicyRepository) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.NetworkPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.policies[policyID]
+	if !ok || policy.TenantID != tenantID {
+		return nil, model.ErrNetworkPolicyNotFound
+	}
+	copied := *policy
+	copied.CIDRs = append([]string{}, policy.CIDRs...)
+	return &copied, nil
+}
+
+func (r *fakeNetworkPolicyRepository) CreatePolicy(ctx context.Context, policy *model.NetworkPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.policies {
+		if existing.TenantID == policy.TenantID && strings.EqualFold(existing.Name, policy.Name) {
+			return model.ErrNetworkPolicyConflict
+		}
+	}
+	copied := *policy
+	r.policies[policy.ID] = &copied
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) UpdatePolicy(ctx context.Context, policy *model.NetworkPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.policies[policy.ID]; !ok || existing.TenantID != policy.TenantID {
+		return model.ErrNetworkPolicyNotFound
+	}
+	copied := *policy
+	r.policies[policy.ID] = &copied
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.policies[policyID]; !ok || existing.TenantID != tenantID {
+		return model.ErrNetworkPolicyNotFound
+	}
+	delete(r.policies, policyID)
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) CreateBreakGlass(ctx context.Context, override *model.BreakGlassOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *override
+	r.overrides[override.ID] = &copied
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) RevokeBreakGlass(ctx context.Context, tenantID, overrideID, revokedBy uuid.UUID, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	override, ok := r.overrides[overrideID]
+	if !ok || override.TenantID != tenantID || override.RevokedAt != nil {
+		return model.ErrBreakGlassNotFound
+	}
+	override.RevokedAt = &revokedAt
+	override.RevokedBy = &revokedBy
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) ListBreakGlass(ctx context.Context, tenantID uuid.UUID, activeAt *time.Time) ([]*model.BreakGlassOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var overrides []*model.BreakGlassOverride
+	for _, override := range r.overrides {
+		if override.TenantID != tenantID || (activeAt != nil && !override.IsActive(*activeAt)) {
+			continue
+		}
+		copied := *override
+		overrides = append(overrides, &copied)
+	}
+	return overrides, nil
+}
+
+func (r *fakeNetworkPolicyRepository) RecordBreakGlassUse(ctx context.Context, use *model.BreakGlassUse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.useErr != nil {
+		return r.useErr
+	}
+	copied := *use
+	r.uses = append(r.uses, &copied)
+	return nil
+}
+
+func (r *fakeNetworkPolicyRepository) ListBreakGlassUses(ctx context.Context, tenantID, overrideID uuid.UUID, limit int) ([]*model.BreakGlassUse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var uses []*model.BreakGlassUse
+	for _, use := range r.uses {
+		if use.TenantID == tenantID && use.OverrideID == overrideID && len(uses) < limit {
+			copied := *use
+			uses = append(uses, &copied)
+		}
+	}
+	return uses, nil
+}
+
+func (r *fakeNetworkPolicyRepository) useCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.uses)
+}
+
+type networkPolicyFixture struct {
+	repo     *fakeNetworkPolicyRepository
+	service  application.NetworkPolicyService
+	tenantID uuid.UUID
+	adminID  uuid.UUID
+}
+
+func newNetworkPolicyFixture() *networkPolicyFixture {
+	f := &networkPolicyFixture{
+		repo:     newFakeNetworkPolicyRepository(),
+		tenantID: uuid.New(),
+		adminID:  uuid.New(),
+	}
+	f.service = impl.NewNetworkPolicyService(f.repo, impl.DefaultNetworkPolicyConfig())
+	return f
+}
+
+func (f *networkPolicyFixture) create(t *testing.T, name string, scope model.NetworkPolicyScope, action model.NetworkPolicyAction, roleCode string, cidrs ...string) *model.NetworkPolicy {
+	policy, err := f.service.CreatePolicy(context.Background(), &application.SaveNetworkPolicyRequest{
+		TenantID: f.tenantID,
+		Name:     name,
+		Scope:    scope,
+		Action:   action,
+		RoleCode: roleCode,
+		CIDRs:    cidrs,
+		Enabled:  true,
+		ActorID:  f.adminID,
+	})
+	require.NoError(t, err)
+	return policy
+}
+
+func (f *networkPolicyFixture) evaluate(t *testing.T, ip string, admin bool, userID uuid.UUID, roles ...string) *model.NetworkDecision {
+	decision, err := f.service.Evaluate(context.Background(), &model.NetworkAccessRequest{
+		TenantID:  f.tenantID,
+		UserID:    userID,
+		Roles:     roles,
+		IPAddress: ip,
+		AdminAPI:  admin,
+		Method:    "GET",
+		Path:      "/api/v1/roles",
+	})
+	require.NoError(t, err)
+	return decision
+}
+
+func TestNetworkPolicyService_NoPoliciesAllows(t *testing.T) {
+	f := newNetworkPolicyFixture()
+
+	decision := f.evaluate(t, "203.0.113.10", true, uuid.New(), "ADMIN")
+	assert.True(t, decision.Allowed)
+	assert.Nil(t, decision.BreakGlassID)
+}
+
+func TestNetworkPolicyService_AdminAllowList(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	f.create(t, "escritório", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", "10.0.0.0/8")
+
+	assert.True(t, f.evaluate(t, "10.1.2.3", true, uuid.Nil).Allowed)
+
+	decision := f.evaluate(t, "203.0.113.10", true, uuid.Nil)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.NetworkBlockReasonNotAllowed, decision.Reason)
+	assert.Equal(t, model.NetworkPolicyScopeAdminAPI, decision.Scope)
+
+	// Fora da API de administração a política não se aplica
+	assert.True(t, f.evaluate(t, "203.0.113.10", false, uuid.Nil).Allowed)
+
+	invalid := f.evaluate(t, "não-é-um-ip", true, uuid.Nil)
+	assert.False(t, invalid.Allowed)
+	assert.Equal(t, model.NetworkBlockReasonInvalidIP, invalid.Reason)
+}
+
+func TestNetworkPolicyService_DenyWins(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	f.create(t, "escritório", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", "10.0.0.0/8")
+	deny := f.create(t, "rede comprometida", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionDeny, "", "10.9.0.0/16")
+
+	decision := f.evaluate(t, "10.9.1.1", true, uuid.Nil)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.NetworkBlockReasonDenied, decision.Reason)
+	require.NotNil(t, decision.PolicyID)
+	assert.Equal(t, deny.ID, *decision.PolicyID)
+}
+
+func TestNetworkPolicyService_RoleAllowLists(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	f.create(t, "tesouraria", model.NetworkPolicyScopeRole, model.NetworkPolicyActionAllow, "TREASURY", "192.0.2.0/24")
+	f.create(t, "auditoria", model.NetworkPolicyScopeRole, model.NetworkPolicyActionAllow, "AUDITOR", "198.51.100.0/24")
+
+	assert.True(t, f.evaluate(t, "192.0.2.5", false, uuid.Nil, "treasury").Allowed)
+	assert.True(t, f.evaluate(t, "203.0.113.10", false, uuid.Nil, "VIEWER").Allowed)
+
+	// A lista de uma função não é contornada pela lista de outra função do mesmo usuário
+	decision := f.evaluate(t, "192.0.2.5", false, uuid.Nil, "TREASURY", "AUDITOR")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.NetworkPolicyScopeRole, decision.Scope)
+	assert.Equal(t, "AUDITOR", decision.RoleCode)
+}
+
+func TestNetworkPolicyService_CacheInvalidation(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	policy := f.create(t, "escritório", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", "10.0.0.0/8")
+
+	assert.False(t, f.evaluate(t, "203.0.113.10", true, uuid.Nil).Allowed)
+	assert.False(t, f.evaluate(t, "203.0.113.10", true, uuid.Nil).Allowed)
+	assert.Equal(t, 1, f.repo.loads, "as decisões seguintes devem vir do cache")
+
+	_, err := f.service.UpdatePolicy(context.Background(), &application.SaveNetworkPolicyRequest{
+		TenantID: f.tenantID,
+		PolicyID: policy.ID,
+		Name:     policy.Name,
+		Scope:    policy.Scope,
+		Action:   policy.Action,
+		CIDRs:    []string{"10.0.0.0/8", "203.0.113.10"},
+		Enabled:  true,
+		ActorID:  f.adminID,
+	})
+	require.NoError(t, err)
+	assert.True(t, f.evaluate(t, "203.0.113.10", true, uuid.Nil).Allowed)
+
+	require.NoError(t, f.service.DeletePolicy(context.Background(), f.tenantID, policy.ID, f.adminID))
+	assert.True(t, f.evaluate(t, "198.51.100.1", true, uuid.Nil).Allowed)
+}
+
+func TestNetworkPolicyService_InvalidPolicy(t *testing.T) {
+	f := newNetworkPolicyFixture()
+
+	tests := []struct {
+		name     string
+		scope    model.NetworkPolicyScope
+		action   model.NetworkPolicyAction
+		roleCode string
+		cidrs    []string
+	}{
+		{"sem blocos", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", nil},
+		{"bloco inválido", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", []string{"10.0.0.0/33"}},
+		{"função sem código", model.NetworkPolicyScopeRole, model.NetworkPolicyActionAllow, "", []string{"10.0.0.0/8"}},
+		{"ação desconhecida", model.NetworkPolicyScopeAdminAPI, "block", "", []string{"10.0.0.0/8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.service.CreatePolicy(context.Background(), &application.SaveNetworkPolicyRequest{
+				TenantID: f.tenantID,
+				Name:     tt.name,
+				Scope:    tt.scope,
+				Action:   tt.action,
+				RoleCode: tt.roleCode,
+				CIDRs:    tt.cidrs,
+				Enabled:  true,
+				ActorID:  f.adminID,
+			})
+			assert.ErrorIs(t, err, model.ErrInvalidNetworkPolicy)
+		})
+	}
+}
+
+func TestNetworkPolicyService_BreakGlass(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	f.create(t, "escritório", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", "10.0.0.0/8")
+	userID := uuid.New()
+
+	override, err := f.service.ActivateBreakGlass(context.Background(), &application.ActivateBreakGlassRequest{
+		TenantID:      f.tenantID,
+		UserID:        userID,
+		Justification: "Incidente em produção fora do escritório",
+		TicketRef:     "INC-42",
+		GrantedBy:     f.adminID,
+	})
+	require.NoError(t, err)
+
+	decision := f.evaluate(t, "203.0.113.10", true, userID)
+	assert.True(t, decision.Allowed)
+	require.NotNil(t, decision.BreakGlassID)
+	assert.Equal(t, override.ID, *decision.BreakGlassID)
+
+	// Cada uso é auditado, mesmo quando a decisão de bloqueio vem do cache
+	f.evaluate(t, "203.0.113.10", true, userID)
+	uses, err := f.service.ListBreakGlassUses(context.Background(), f.tenantID, override.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, uses, 2)
+	assert.Equal(t, "203.0.113.10", uses[0].IPAddress)
+	assert.Equal(t, model.NetworkBlockReasonNotAllowed, uses[0].BlockedReason)
+
+	// Outro usuário continua bloqueado
+	assert.False(t, f.evaluate(t, "203.0.113.10", true, uuid.New()).Allowed)
+
+	require.NoError(t, f.service.RevokeBreakGlass(context.Background(), f.tenantID, override.ID, f.adminID))
+	assert.False(t, f.evaluate(t, "203.0.113.10", true, userID).Allowed)
+	assert.Equal(t, 2, f.repo.useCount())
+}
+
+func TestNetworkPolicyService_BreakGlassAuditFailure(t *testing.T) {
+	f := newNetworkPolicyFixture()
+	f.create(t, "escritório", model.NetworkPolicyScopeAdminAPI, model.NetworkPolicyActionAllow, "", "10.0.0.0/8")
+	userID := uuid.New()
+
+	_, err := f.service.ActivateBreakGlass(context.Background(), &application.ActivateBreakGlassRequest{
+		TenantID:      f.tenantID,
+		UserID:        userID,
+		Justification: "Incidente em produção fora do escritório",
+		GrantedBy:     f.adminID,
+	})
+	require.NoError(t, err)
+
+	f.repo.useErr = errors.New("base de dados indisponível")
+	decision := f.evaluate(t, "203.0.113.10", true, userID)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.NetworkBlockReasonAuditFailure, decision.Reason)
+	assert.Nil(t, decision.BreakGlassID)
+}
+
+func TestNetworkPolicyService_ActivateBreakGlassValidation(t *testing.T) {
+	f := newNetworkPolicyFixture()
+
+	tests := []struct {
+		name string
+		req  application.ActivateBreakGlassRequest
+	}{
+		{"justificação curta", application.ActivateBreakGlassRequest{
+			TenantID: f.tenantID, UserID: uuid.New(), Justification: "urgente", GrantedBy: f.adminID,
+		}},
+		{"sem responsável", application.ActivateBreakGlassRequest{
+			TenantID: f.tenantID, UserID: uuid.New(), Justification: "Incidente em produção fora do escritório",
+		}},
+		{"duração acima do máximo", application.ActivateBreakGlassRequest{
+			TenantID: f.tenantID, UserID: uuid.New(), Justification: "Incidente em produção fora do escritório",
+			GrantedBy: f.adminID, Duration: 24 * time.Hour,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.service.ActivateBreakGlass(context.Background(), &tt.req)
+			assert.ErrorIs(t, err, model.ErrInvalidBreakGlass)
+		})
+	}
+}
@@ -0,0 +1,606 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a autenticação sem senha (PasswordlessService).
+ * Valida a política de mercado para tenants financeiros, o uso único e a assinatura dos
+ * links mágicos, o limite de tentativas dos códigos, o limite de pedidos e a vinculação ao dispositivo.
+ */
+
+package test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakePasswordlessRepository é um PasswordlessRepository em memória
+type fakePasswordlessRepository struct {
+	mu         sync.Mutex
+	settings   map[uuid.UUID]*model.PasswordlessSettings
+	users      map[uuid.UUID]*model.User
+	challenges map[uuid.UUID]*model.PasswordlessChallenge
+	logins     map[uuid.UUID]int
+}
+
+func newFakePasswordlessRepository() *fakePasswordlessRepository {
+	return &fakePasswordlessRepository{
+		settings:   make(map[uuid.UUID]*model.PasswordlessSettings),
+		users:      make(map[uuid.UUID]*model.User),
+		challenges: make(map[uuid.UUID]*model.PasswordlessChallenge),
+		logins:     make(map[uuid.UUID]int),
+	}
+}
+
+func (r *fakePasswordlessRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.PasswordlessSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, ok := r.settings[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *settings
+	copied.Methods = append([]model.PasswordlessMethod{}, settings.Methods...)
+	return &copied, nil
+}
+
+func (r *fakePasswordlessRepository) SaveSettings(ctx context.Context, settings *model.PasswordlessSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *settings
+	copied.Methods = append([]model.PasswordlessMethod{}, settings.Methods...)
+	r.settings[settings.TenantID] = &copied
+	return nil
+}
+
+func (r *fakePasswordlessRepository) FindUserByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.TenantID == tenantID && strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, model.ErrUserNotFound
+}
+
+func (r *fakePasswordlessRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, model.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakePasswordlessRepository) CountRecentChallenges(ctx context.Context, tenantID uuid.UUID, email, ipAddress string, since time.Time) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byEmail, byIP := 0, 0
+	for _, challenge := range r.challenges {
+		if challenge.TenantID != tenantID || challenge.CreatedAt.Before(since) {
+			continue
+		}
+		if challenge.Email == email {
+			byEmail++
+		}
+		if ipAddress != "" && challenge.IPAddress == ipAddress {
+			byIP++
+		}
+	}
+	return byEmail, byIP, nil
+}
+
+func (r *fakePasswordlessRepository) CreateChallenge(ctx context.Context, challenge *model.PasswordlessChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *challenge
+	r.challenges[challenge.ID] = &copied
+	return nil
+}
+
+func (r *fakePasswordlessRepository) ClaimAttempt(ctx context.Context, challengeID uuid.UUID, now time.Time) (*model.PasswordlessChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[challengeID]
+	if !ok || challenge.ConsumedAt != nil || !challenge.ExpiresAt.After(now) || challenge.Attempts >= challenge.MaxAttempts {
+		return nil, model.ErrPasswordlessChallengeNotFound
+	}
+	challenge.Attempts++
+	copied := *challenge
+	return &copied, nil
+}
+
+func (r *fakePasswordlessRepository) ConsumeChallenge(ctx context.Context, challengeID uuid.UUID, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[challengeID]
+	if !ok || challenge.ConsumedAt != nil || !challenge.ExpiresAt.After(now) || challenge.UserID == uuid.Nil {
+		return model.ErrPasswordlessChallengeNotFound
+	}
+	challenge.ConsumedAt = &now
+	r.logins[challenge.UserID]++
+	return nil
+}
+
+// expire faz expirar todos os desafios emitidos
+func (r *fakePasswordlessRepository) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, challenge := range r.challenges {
+		challenge.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+}
+
+func (r *fakePasswordlessRepository) loginCount(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.logins[userID]
+}
+
+// fakePasswordlessSender regista os links e os códigos enviados
+type fakePasswordlessSender struct {
+	mu    sync.Mutex
+	links map[string]string
+	codes map[string]string
+	sent  int
+}
+
+func newFakePasswordlessSender() *fakePasswordlessSender {
+	return &fakePasswordlessSender{links: make(map[string]string), codes: make(map[string]string)}
+}
+
+func (s *fakePasswordlessSender) SendMagicLink(ctx context.Context, tenantID uuid.UUID, email, link string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links[email] = link
+	s.sent++
+	return nil
+}
+
+func (s *fakePasswordlessSender) SendOTP(ctx context.Context, tenantID uuid.UUID, email, code string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[email] = code
+	s.sent++
+	return nil
+}
+
+func (s *fakePasswordlessSender) token(t *testing.T, email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[email]
+	require.True(t, ok, "link mágico não enviado para %s", email)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+func (s *fakePasswordlessSender) code(t *testing.T, email string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.codes[email]
+	require.True(t, ok, "código não enviado para %s", email)
+	return code
+}
+
+func (s *fakePasswordlessSender) sentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+type passwordlessFixture struct {
+	repo     *fakePasswordlessRepository
+	sender   *fakePasswordlessSender
+	legal    *fakeLegalAcceptanceGate
+	service  application.PasswordlessService
+	tenantID uuid.UUID
+	user     *model.User
+}
+
+func newPasswordlessFixture(t *testing.T, configure func(*impl.PasswordlessConfig)) *passwordlessFixture {
+	config := impl.DefaultPasswordlessConfig()
+	config.SigningKey = []byte("chave-de-teste-com-pelo-menos-32-bytes!!")
+	config.LinkBaseURL = "https://app.innovabiz.test/auth/passwordless"
+	if configure != nil {
+		configure(&config)
+	}
+
+	f := &passwordlessFixture{
+		repo:     newFakePasswordlessRepository(),
+		sender:   newFakePasswordlessSender(),
+		legal:    &fakeLegalAcceptanceGate{},
+		tenantID: uuid.New(),
+	}
+	f.service = impl.NewPasswordlessService(f.repo, f.sender, f.legal, config)
+
+	user, err := model.NewUser(f.tenantID, "ana", "ana@innovabiz.test", "Ana", "Silva")
+	require.NoError(t, err)
+	user.Status = model.UserStatusActive
+	f.user = user
+	f.repo.users[user.ID] = user
+	return f
+}
+
+func (f *passwordlessFixture) enable(t *testing.T, binding model.PasswordlessDeviceBinding) {
+	_, err := f.service.UpdateSettings(context.Background(), &application.UpdatePasswordlessSettingsRequest{
+		TenantID:      f.tenantID,
+		Enabled:       true,
+		Methods:       []model.PasswordlessMethod{model.PasswordlessMethodMagicLink, model.PasswordlessMethodEmailOTP},
+		DeviceBinding: binding,
+		Market:        "angola",
+		UpdatedBy:     uuid.New(),
+	})
+	require.NoError(t, err)
+}
+
+func (f *passwordlessFixture) start(method model.PasswordlessMethod, email, deviceID string) (*application.PasswordlessChallengeIssued, error) {
+	return f.service.StartLogin(context.Background(), &application.StartPasswordlessLoginRequest{
+		TenantID:  f.tenantID,
+		Email:     email,
+		Method:    method,
+		DeviceID:  deviceID,
+		IPAddress: "198.51.100.7",
+	})
+}
+
+func TestPasswordlessService_MarketPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		market    string
+		financial bool
+		enabled   bool
+		binding   model.PasswordlessDeviceBinding
+		want      error
+	}{
+		{"tenant não financeiro na UE", "eu", false, true, model.PasswordlessDeviceBindingNone, nil},
+		{"tenant financeiro na UE", "eu", true, true, model.PasswordlessDeviceBindingRequired, model.ErrPasswordlessNotAllowed},
+		{"tenant financeiro em Angola", "angola", true, true, model.PasswordlessDeviceBindingRequired, model.ErrPasswordlessNotAllowed},
+		{"tenant financeiro sem mercado", "", true, true, model.PasswordlessDeviceBindingRequired, model.ErrPasswordlessNotAllowed},
+		{"tenant financeiro no Brasil sem vinculação", "brazil", true, true, model.PasswordlessDeviceBindingOptional, model.ErrInvalidPasswordlessSettings},
+		{"tenant financeiro no Brasil com vinculação", "brazil", true, true, model.PasswordlessDeviceBindingRequired, nil},
+		{"tenant financeiro nos EUA com vinculação", "USA", true, true, model.PasswordlessDeviceBindingRequired, nil},
+		{"tenant financeiro na UE desativado", "eu", true, false, model.PasswordlessDeviceBindingNone, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPasswordlessFixture(t, nil)
+			settings, err := f.service.UpdateSettings(context.Background(), &application.UpdatePasswordlessSettingsRequest{
+				TenantID:        f.tenantID,
+				Enabled:         tt.enabled,
+				Methods:         []model.PasswordlessMethod{model.PasswordlessMethodMagicLink},
+				DeviceBinding:   tt.binding,
+				Market:          tt.market,
+				FinancialTenant: tt.financial,
+			})
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToLower(tt.market), settings.Market)
+		})
+	}
+
+	t.Run("métodos inválidos", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		_, err := f.service.UpdateSettings(context.Background(), &application.UpdatePasswordlessSettingsRequest{
+			TenantID: f.tenantID,
+			Enabled:  true,
+			Methods:  []model.PasswordlessMethod{"sms"},
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordlessSettings)
+	})
+}
+
+func TestPasswordlessService_Disabled(t *testing.T) {
+	f := newPasswordlessFixture(t, nil)
+
+	settings, err := f.service.GetSettings(context.Background(), f.tenantID)
+	require.NoError(t, err)
+	assert.False(t, settings.Enabled)
+
+	_, err = f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+	assert.ErrorIs(t, err, model.ErrPasswordlessDisabled)
+	assert.Zero(t, f.sender.sentCount())
+}
+
+func TestPasswordlessService_MagicLink(t *testing.T) {
+	f := newPasswordlessFixture(t, nil)
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+	ctx := context.Background()
+
+	issued, err := f.start(model.PasswordlessMethodMagicLink, "  ANA@innovabiz.test ", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.PasswordlessMethodMagicLink, issued.Method)
+
+	token := f.sender.token(t, f.user.Email)
+	require.NotEmpty(t, token)
+
+	t.Run("assinatura adulterada", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+		forged := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+		_, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{TenantID: f.tenantID, Token: forged})
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+	})
+
+	t.Run("outro tenant", func(t *testing.T) {
+		_, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{TenantID: uuid.New(), Token: token})
+		assert.Error(t, err)
+	})
+
+	result, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{TenantID: f.tenantID, Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, result.User.ID)
+	assert.False(t, result.DeviceBound)
+	assert.Equal(t, 1, f.repo.loginCount(f.user.ID))
+
+	t.Run("uso único", func(t *testing.T) {
+		_, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{TenantID: f.tenantID, Token: token})
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+		assert.Equal(t, 1, f.repo.loginCount(f.user.ID))
+	})
+}
+
+func TestPasswordlessService_PendingLegalDocuments(t *testing.T) {
+	f := newPasswordlessFixture(t, nil)
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+	terms := &model.LegalDocument{ID: uuid.New(), TenantID: f.tenantID, Type: model.LegalDocumentTermsOfService, Required: true}
+	f.legal.pending = []*model.LegalDocument{terms}
+
+	_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+	require.NoError(t, err)
+	token := f.sender.token(t, f.user.Email)
+
+	result, err := f.service.VerifyMagicLink(context.Background(), &application.VerifyPasswordlessRequest{TenantID: f.tenantID, Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, result.User.ID)
+	assert.Equal(t, []*model.LegalDocument{terms}, result.PendingDocuments)
+
+	// O desafio fica consumido: o link não pode ser reutilizado depois da aceitação
+	_, err = f.service.VerifyMagicLink(context.Background(), &application.VerifyPasswordlessRequest{TenantID: f.tenantID, Token: token})
+	assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+}
+
+// fakeLoginObserver regista os logins observados
+type fakeLoginObserver struct {
+	events chan *model.LoginEvent
+}
+
+func (o *fakeLoginObserver) ObserveLogin(ctx context.Context, event *model.LoginEvent) error {
+	o.events <- event
+	return nil
+}
+
+func TestPasswordlessService_LoginObserver(t *testing.T) {
+	observer := &fakeLoginObserver{events: make(chan *model.LoginEvent, 1)}
+	f := newPasswordlessFixture(t, func(config *impl.PasswordlessConfig) {
+		config.LoginObserver = observer
+	})
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+
+	_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+	require.NoError(t, err)
+	_, err = f.service.VerifyMagicLink(context.Background(), &application.VerifyPasswordlessRequest{
+		TenantID:  f.tenantID,
+		Token:     f.sender.token(t, f.user.Email),
+		IPAddress: "197.149.90.10",
+		UserAgent: "Mozilla/5.0",
+		Country:   "AO",
+	})
+	require.NoError(t, err)
+
+	select {
+	case event := <-observer.events:
+		assert.Equal(t, f.tenantID, event.TenantID)
+		assert.Equal(t, f.user.ID, event.UserID)
+		assert.Equal(t, string(model.PasswordlessMethodMagicLink), event.Method)
+		assert.Equal(t, "197.149.90.10", event.IPAddress)
+		assert.Equal(t, "AO", event.Country)
+	case <-time.After(time.Second):
+		t.Fatal("login não observado")
+	}
+
+	t.Run("documentos legais por aceitar", func(t *testing.T) {
+		f.legal.pending = []*model.LegalDocument{{ID: uuid.New(), TenantID: f.tenantID, Required: true}}
+		_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+		require.NoError(t, err)
+		_, err = f.service.VerifyMagicLink(context.Background(), &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID,
+			Token:    f.sender.token(t, f.user.Email),
+		})
+		require.NoError(t, err)
+
+		// O login só fica concluído depois da aceitação dos documentos
+		select {
+		case <-observer.events:
+			t.Fatal("login observado antes da aceitação dos documentos")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestPasswordlessService_MagicLinkExpired(t *testing.T) {
+	f := newPasswordlessFixture(t, nil)
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+
+	_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+	require.NoError(t, err)
+	f.repo.expire()
+
+	_, err = f.service.VerifyMagicLink(context.Background(), &application.VerifyPasswordlessRequest{
+		TenantID: f.tenantID,
+		Token:    f.sender.token(t, f.user.Email),
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+}
+
+func TestPasswordlessService_OTP(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("código correto", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		f.enable(t, model.PasswordlessDeviceBindingNone)
+
+		issued, err := f.start(model.PasswordlessMethodEmailOTP, f.user.Email, "")
+		require.NoError(t, err)
+		code := f.sender.code(t, f.user.Email)
+		assert.Len(t, code, impl.DefaultPasswordlessOTPDigits)
+
+		result, err := f.service.VerifyOTP(ctx, &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID, ChallengeID: issued.ChallengeID, Code: code,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PasswordlessMethodEmailOTP, result.Method)
+	})
+
+	t.Run("tentativas esgotadas", func(t *testing.T) {
+		f := newPasswordlessFixture(t, func(c *impl.PasswordlessConfig) { c.MaxAttempts = 3 })
+		f.enable(t, model.PasswordlessDeviceBindingNone)
+
+		issued, err := f.start(model.PasswordlessMethodEmailOTP, f.user.Email, "")
+		require.NoError(t, err)
+		code := f.sender.code(t, f.user.Email)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for i := 0; i < 3; i++ {
+			_, err := f.service.VerifyOTP(ctx, &application.VerifyPasswordlessRequest{
+				TenantID: f.tenantID, ChallengeID: issued.ChallengeID, Code: wrong,
+			})
+			assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+		}
+
+		_, err = f.service.VerifyOTP(ctx, &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID, ChallengeID: issued.ChallengeID, Code: code,
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+		assert.Zero(t, f.repo.loginCount(f.user.ID))
+	})
+
+	t.Run("método não aceite", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		_, err := f.service.UpdateSettings(ctx, &application.UpdatePasswordlessSettingsRequest{
+			TenantID: f.tenantID,
+			Enabled:  true,
+			Methods:  []model.PasswordlessMethod{model.PasswordlessMethodMagicLink},
+		})
+		require.NoError(t, err)
+
+		_, err = f.start(model.PasswordlessMethodEmailOTP, f.user.Email, "")
+		assert.ErrorIs(t, err, model.ErrPasswordlessMethodNotAllowed)
+	})
+}
+
+func TestPasswordlessService_UnknownEmail(t *testing.T) {
+	f := newPasswordlessFixture(t, nil)
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+
+	issued, err := f.start(model.PasswordlessMethodEmailOTP, "desconhecido@innovabiz.test", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, issued.ChallengeID)
+	assert.Zero(t, f.sender.sentCount())
+
+	_, err = f.service.VerifyOTP(context.Background(), &application.VerifyPasswordlessRequest{
+		TenantID: f.tenantID, ChallengeID: issued.ChallengeID, Code: "123456",
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+}
+
+func TestPasswordlessService_RateLimit(t *testing.T) {
+	f := newPasswordlessFixture(t, func(c *impl.PasswordlessConfig) {
+		c.MaxPerEmail = 2
+		c.MaxPerIP = 3
+	})
+	f.enable(t, model.PasswordlessDeviceBindingNone)
+
+	for i := 0; i < 2; i++ {
+		_, err := f.start(model.PasswordlessMethodEmailOTP, f.user.Email, "")
+		require.NoError(t, err)
+	}
+	_, err := f.start(model.PasswordlessMethodEmailOTP, f.user.Email, "")
+	assert.ErrorIs(t, err, model.ErrPasswordlessRateLimited)
+
+	// O limite por endereço IP abrange também os emails sem conta
+	_, err = f.start(model.PasswordlessMethodEmailOTP, "outro@innovabiz.test", "")
+	require.NoError(t, err)
+	_, err = f.start(model.PasswordlessMethodEmailOTP, "mais-um@innovabiz.test", "")
+	assert.ErrorIs(t, err, model.ErrPasswordlessRateLimited)
+}
+
+func TestPasswordlessService_DeviceBinding(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("obrigatória sem dispositivo", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		f.enable(t, model.PasswordlessDeviceBindingRequired)
+
+		_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+		assert.ErrorIs(t, err, model.ErrPasswordlessDeviceRequired)
+	})
+
+	t.Run("concluído noutro dispositivo", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		f.enable(t, model.PasswordlessDeviceBindingRequired)
+
+		_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "telemovel-1")
+		require.NoError(t, err)
+		token := f.sender.token(t, f.user.Email)
+
+		_, err = f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID, Token: token, DeviceID: "portatil-2",
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidPasswordlessToken)
+
+		result, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID, Token: token, DeviceID: "telemovel-1",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.DeviceBound)
+	})
+
+	t.Run("opcional sem dispositivo", func(t *testing.T) {
+		f := newPasswordlessFixture(t, nil)
+		f.enable(t, model.PasswordlessDeviceBindingOptional)
+
+		_, err := f.start(model.PasswordlessMethodMagicLink, f.user.Email, "")
+		require.NoError(t, err)
+
+		result, err := f.service.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{
+			TenantID: f.tenantID, Token: f.sender.token(t, f.user.Email), DeviceID: "qualquer",
+		})
+		require.NoError(t, err)
+		assert.False(t, result.DeviceBound)
+	})
+}
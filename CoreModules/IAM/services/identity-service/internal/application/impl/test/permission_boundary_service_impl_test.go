@@ -0,0 +1,358 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para os limites de permissões (PermissionBoundaryService).
+ * Valida os limites das funções e dos administradores delegados, a recusa das concessões
+ * fora dos limites, o registo das violações como incidentes de severidade alta e os
+ * limites expostos às políticas do PDP.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakePermissionBoundaryRepository é um PermissionBoundaryRepository em memória
+type fakePermissionBoundaryRepository struct {
+	mu         sync.Mutex
+	boundaries map[uuid.UUID]*model.PermissionBoundary
+	roles      map[uuid.UUID]string
+	users      map[uuid.UUID]bool
+	listCalls  int
+}
+
+func newFakePermissionBoundaryRepository() *fakePermissionBoundaryRepository {
+	return &fakePermissionBoundaryRepository{
+		boundaries: make(map[uuid.UUID]*model.PermissionBoundary),
+		roles:      make(map[uuid.UUID]string),
+		users:      make(map[uuid.UUID]bool),
+	}
+}
+
+func (r *fakePermissionBoundaryRepository) List(ctx context.Context, tenantID uuid.UUID) ([]*model.PermissionBoundary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listCalls++
+	var boundaries []*model.PermissionBoundary
+	for _, boundary := range r.boundaries {
+		if boundary.TenantID == tenantID {
+			copied := *boundary
+			copied.RoleCode = r.roles[boundary.SubjectID]
+			boundaries = append(boundaries, &copied)
+		}
+	}
+	return boundaries, nil
+}
+
+func (r *fakePermissionBoundaryRepository) Get(ctx context.Context, tenantID, boundaryID uuid.UUID) (*model.PermissionBoundary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	boundary, ok := r.boundaries[boundaryID]
+	if !ok || boundary.TenantID != tenantID {
+		return nil, model.ErrPermissionBoundaryNotFound
+	}
+	copied := *boundary
+	copied.RoleCode = r.roles[boundary.SubjectID]
+	return &copied, nil
+}
+
+func (r *fakePermissionBoundaryRepository) Create(ctx context.Context, boundary *model.PermissionBoundary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, isRole := r.roles[boundary.SubjectID]
+	if boundary.SubjectType == model.PermissionBoundarySubjectRole && !isRole ||
+		boundary.SubjectType == model.PermissionBoundarySubjectDelegatedAdmin && !r.users[boundary.SubjectID] {
+		return model.ErrPermissionBoundarySubjectNotFound
+	}
+	for _, existing := range r.boundaries {
+		if existing.TenantID == boundary.TenantID && existing.SubjectType == boundary.SubjectType &&
+			existing.SubjectID == boundary.SubjectID {
+			return model.ErrPermissionBoundaryConflict
+		}
+	}
+	copied := *boundary
+	r.boundaries[boundary.ID] = &copied
+	boundary.RoleCode = r.roles[boundary.SubjectID]
+	return nil
+}
+
+func (r *fakePermissionBoundaryRepository) Update(ctx context.Context, boundary *model.PermissionBoundary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.boundaries[boundary.ID]; !ok {
+		return model.ErrPermissionBoundaryNotFound
+	}
+	copied := *boundary
+	r.boundaries[boundary.ID] = &copied
+	return nil
+}
+
+func (r *fakePermissionBoundaryRepository) Delete(ctx context.Context, tenantID, boundaryID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	boundary, ok := r.boundaries[boundaryID]
+	if !ok || boundary.TenantID != tenantID {
+		return model.ErrPermissionBoundaryNotFound
+	}
+	delete(r.boundaries, boundaryID)
+	return nil
+}
+
+func (r *fakePermissionBoundaryRepository) lists() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.listCalls
+}
+
+func newTestPermissionBoundaryService() (application.PermissionBoundaryService, *fakePermissionBoundaryRepository, *fakeSecurityIncidentRepository, *recordingPublisher) {
+	repo := newFakePermissionBoundaryRepository()
+	incidents := newFakeSecurityIncidentRepository()
+	publisher := &recordingPublisher{}
+	return impl.NewPermissionBoundaryService(repo, incidents, publisher, 0), repo, incidents, publisher
+}
+
+func TestPermissionBoundary_CreateNormalizesAndValidates(t *testing.T) {
+	service, repo, _, _ := newTestPermissionBoundaryService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	roleID := uuid.New()
+	repo.roles[roleID] = "support_agent"
+
+	boundary, err := service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID:    tenantID,
+		SubjectType: model.PermissionBoundarySubjectRole,
+		SubjectID:   roleID,
+		Name:        " Suporte ",
+		Permissions: []string{"users:read", "roles:*", " users:read", "*:*"},
+		ActorID:     uuid.New(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Suporte", boundary.Name)
+	assert.Equal(t, []string{"*", "roles:*", "users:read"}, boundary.Permissions)
+	assert.Equal(t, "support_agent", boundary.RoleCode)
+
+	// Um segundo limite para o mesmo titular é recusado
+	_, err = service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectRole, SubjectID: roleID, Name: "Outro",
+	})
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundaryConflict))
+
+	// Titular inexistente, tipo desconhecido e padrões inválidos
+	_, err = service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectDelegatedAdmin, SubjectID: uuid.New(), Name: "Admin",
+	})
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundarySubjectNotFound))
+
+	_, err = service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: "group", SubjectID: roleID, Name: "Grupo",
+	})
+	assert.True(t, errors.Is(err, application.ErrInvalidPermissionBoundary))
+
+	for _, pattern := range []string{"roles:*:read", "*:read", "roles read", "roles:"} {
+		_, err = service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+			TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectRole, SubjectID: roleID, Name: "Inválido",
+			Permissions: []string{pattern},
+		})
+		assert.True(t, errors.Is(err, application.ErrInvalidPermissionBoundary), pattern)
+	}
+}
+
+func TestPermissionBoundary_UpdateKeepsSubject(t *testing.T) {
+	service, repo, _, _ := newTestPermissionBoundaryService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	adminID := uuid.New()
+	repo.users[adminID] = true
+
+	created, err := service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectDelegatedAdmin, SubjectID: adminID,
+		Name: "Administrador regional", Permissions: []string{"roles:read"},
+	})
+	require.NoError(t, err)
+
+	updated, err := service.UpdateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, BoundaryID: created.ID, Name: "Administrador regional",
+		Permissions: []string{"roles:read", "roles:assign_user"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"roles:assign_user", "roles:read"}, updated.Permissions)
+	assert.Equal(t, adminID, updated.SubjectID)
+
+	_, err = service.UpdateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, BoundaryID: created.ID, SubjectID: uuid.New(), Name: "Outro titular",
+	})
+	assert.True(t, errors.Is(err, application.ErrInvalidPermissionBoundary))
+
+	require.NoError(t, service.DeleteBoundary(ctx, tenantID, created.ID, uuid.New()))
+	_, err = service.GetBoundary(ctx, tenantID, created.ID)
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundaryNotFound))
+}
+
+func TestPermissionBoundary_CheckGrantRejectsRoleEscalation(t *testing.T) {
+	service, repo, incidents, publisher := newTestPermissionBoundaryService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	parentID := uuid.New()
+	childID := uuid.New()
+	repo.roles[childID] = "support_agent"
+
+	_, err := service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectRole, SubjectID: childID,
+		Name: "Suporte", Permissions: []string{"users:read", "roles:*"},
+	})
+	require.NoError(t, err)
+
+	actorID := uuid.New()
+	permissionID := uuid.New()
+
+	// Dentro do limite, incluindo os padrões com prefixo
+	require.NoError(t, service.CheckGrant(ctx, &application.PermissionBoundaryCheck{
+		TenantID: tenantID, ActorID: actorID, Operation: model.PermissionBoundaryOperationAssignPermission,
+		RoleID: childID, RoleCode: "support_agent", BoundedRoleIDs: []uuid.UUID{childID},
+		SubjectIDs: []uuid.UUID{permissionID}, Permissions: []string{"roles:assign_user", "users:read"},
+	}))
+	assert.Empty(t, incidents.incidents)
+
+	// A função pai herda e não tem limite; a filha recebe as permissões da pai por herança
+	err = service.CheckGrant(ctx, &application.PermissionBoundaryCheck{
+		TenantID: tenantID, ActorID: actorID, Operation: model.PermissionBoundaryOperationAssignChildRole,
+		RoleID: parentID, RoleCode: "platform_admin", BoundedRoleIDs: []uuid.UUID{childID},
+		SubjectIDs: []uuid.UUID{childID}, Permissions: []string{"users:delete", "users:read", "users:delete"},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundaryViolation))
+	assert.Contains(t, err.Error(), "users:delete")
+
+	require.Len(t, incidents.incidents, 1)
+	for _, incident := range incidents.incidents {
+		assert.Equal(t, model.SecurityIncidentRulePermissionBoundaryViolation, incident.Rule)
+		assert.Equal(t, model.SecurityIncidentSeverityHigh, incident.Severity)
+		assert.Equal(t, tenantID, incident.TenantID)
+		require.NotNil(t, incident.ActorID)
+		assert.Equal(t, actorID, *incident.ActorID)
+		assert.Contains(t, incident.Summary, "users:delete")
+		assert.NotContains(t, incident.Summary, "users:read")
+		require.Len(t, incident.Evidence, 1)
+		assert.Equal(t, "permission_boundary.assign_child_role", incident.Evidence[0].EventType)
+		assert.Equal(t, []uuid.UUID{childID}, incident.Evidence[0].SubjectIDs)
+	}
+
+	events := publisher.published()
+	require.Len(t, events, 1)
+	assert.IsType(t, &event.SecurityAnomalyDetectedEvent{}, events[0])
+}
+
+func TestPermissionBoundary_CheckGrantRejectsDelegatedAdmin(t *testing.T) {
+	service, repo, incidents, _ := newTestPermissionBoundaryService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	adminID := uuid.New()
+	repo.users[adminID] = true
+
+	_, err := service.CreateBoundary(ctx, &application.SavePermissionBoundaryRequest{
+		TenantID: tenantID, SubjectType: model.PermissionBoundarySubjectDelegatedAdmin, SubjectID: adminID,
+		Name: "Administrador de suporte", Permissions: []string{"users:*"},
+	})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	roleID := uuid.New()
+	check := &application.PermissionBoundaryCheck{
+		TenantID: tenantID, ActorID: adminID, Operation: model.PermissionBoundaryOperationAssignUser,
+		RoleID: roleID, RoleCode: "auditor", SubjectIDs: []uuid.UUID{userID},
+		Permissions: []string{"audit:read", "users:read"},
+	}
+	err = service.CheckGrant(ctx, check)
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundaryViolation))
+	require.Len(t, incidents.incidents, 1)
+
+	// Outro administrador, sem limite, pode conceder as mesmas permissões
+	check.ActorID = uuid.New()
+	require.NoError(t, service.CheckGrant(ctx, check))
+	assert.Len(t, incidents.incidents, 1)
+
+	// A falha do registo do incidente não deixa passar a concessão
+	check.ActorID = adminID
+	incidents.createErr = errors.New("base de dados indisponível")
+	err = service.CheckGrant(ctx, check)
+	assert.True(t, errors.Is(err, application.ErrPermissionBoundaryViolation))
+}
+
+func TestPermissionBoundary_ResolvePolicyBoundaries(t *testing.T) {
+	service, repo, _, _ := newTestPermissionBoundaryService()
+	ctx := context.Background()
+	tenantID := uuid.New()
+	userID := uuid.New()
+
+	// Sem limites no tenant, as políticas não recebem limites
+	input, err := service.ResolvePolicyBoundaries(ctx, tenantID, userID, []string{"support_agent"})
+	require.NoError(t, err)
+	assert.Nil(t, input)
+
+	roleID := uuid.New()
+	otherRoleID := uuid.New()
+	repo.roles[roleID] = "support_agent"
+	repo.roles[otherRoleID] = "billing"
+	repo.users[userID] = true
+	for _, req := range []*application.SavePermissionBoundaryRequest{
+		{SubjectType: model.PermissionBoundarySubjectRole, SubjectID: roleID, Name: "Suporte", Permissions: []string{"users:read"}},
+		{SubjectType: model.PermissionBoundarySubjectRole, SubjectID: otherRoleID, Name: "Faturação", Permissions: []string{"billing:*"}},
+		{SubjectType: model.PermissionBoundarySubjectDelegatedAdmin, SubjectID: userID, Name: "Administrador", Permissions: []string{}},
+	} {
+		req.TenantID = tenantID
+		_, err := service.CreateBoundary(ctx, req)
+		require.NoError(t, err)
+	}
+
+	input, err = service.ResolvePolicyBoundaries(ctx, tenantID, userID, []string{"Support_Agent", "role_viewer"})
+	require.NoError(t, err)
+	require.NotNil(t, input)
+	assert.Equal(t, map[string][]string{"Support_Agent": {"users:read"}}, input.Roles)
+	// O limite vazio não permite nenhuma permissão e não se confunde com a ausência de limite
+	require.NotNil(t, input.User)
+	assert.Empty(t, input.User)
+
+	// Os limites ficam em cache até à próxima alteração
+	lists := repo.lists()
+	_, err = service.ResolvePolicyBoundaries(ctx, tenantID, uuid.New(), []string{"billing"})
+	require.NoError(t, err)
+	assert.Equal(t, lists, repo.lists())
+
+	other, err := service.ResolvePolicyBoundaries(ctx, tenantID, uuid.New(), []string{"billing"})
+	require.NoError(t, err)
+	assert.Nil(t, other.User)
+	assert.Equal(t, []string{"billing:*"}, other.Roles["billing"])
+}
+
+func TestPermissionBoundary_PatternMatching(t *testing.T) {
+	boundary := &model.PermissionBoundary{Permissions: []string{"roles:*", "users:read"}}
+
+	assert.True(t, boundary.Allows("roles:assign_user"))
+	assert.True(t, boundary.Allows("users:read"))
+	assert.False(t, boundary.Allows("users:read_all"))
+	assert.False(t, boundary.Allows("rolesx:read"))
+	assert.False(t, boundary.Allows("*:*"))
+	assert.Equal(t, []string{"audit:read", "users:delete"}, boundary.Exceeding([]string{"users:delete", "roles:read", "audit:read", "users:delete"}))
+
+	assert.True(t, model.PermissionPatternMatches("*", "*:*"))
+}
@@ -0,0 +1,214 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a auditoria das decisões de autorização (PermissionDecisionAuditService).
+ * Valida a amostragem das permissões, o registo integral das negações, a gravação em lote,
+ * a retoma após falhas do armazenamento e a validação dos filtros de consulta.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakePermissionDecisionRepository é um PermissionDecisionRepository em memória
+type fakePermissionDecisionRepository struct {
+	mu         sync.Mutex
+	decisions  []*model.PermissionDecision
+	batches    []int
+	appendErr  error
+	lastFilter model.PermissionDecisionFilter
+}
+
+func (r *fakePermissionDecisionRepository) AppendBatch(ctx context.Context, decisions []*model.PermissionDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.appendErr != nil {
+		return r.appendErr
+	}
+	r.batches = append(r.batches, len(decisions))
+	r.decisions = append(r.decisions, decisions...)
+	return nil
+}
+
+func (r *fakePermissionDecisionRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.PermissionDecisionFilter) ([]*model.PermissionDecision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastFilter = filter
+	var decisions []*model.PermissionDecision
+	for _, decision := range r.decisions {
+		if decision.TenantID == tenantID {
+			decisions = append(decisions, decision)
+		}
+	}
+	return decisions, nil
+}
+
+func (r *fakePermissionDecisionRepository) stored() []*model.PermissionDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.PermissionDecision(nil), r.decisions...)
+}
+
+func (r *fakePermissionDecisionRepository) failWith(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appendErr = err
+}
+
+// newDecision cria uma decisão de autorização para o recurso indicado
+func newDecision(tenantID, userID uuid.UUID, resource string, allowed bool) *model.PermissionDecision {
+	return &model.PermissionDecision{
+		TenantID: tenantID,
+		UserID:   userID,
+		Action:   "GET",
+		Resource: resource,
+		Allowed:  allowed,
+	}
+}
+
+// countAllowed conta as permissões e as negações de uma lista de decisões
+func countAllowed(decisions []*model.PermissionDecision) (allowed, denied int) {
+	for _, decision := range decisions {
+		if decision.Allowed {
+			allowed++
+		} else {
+			denied++
+		}
+	}
+	return allowed, denied
+}
+
+// TestPermissionDecisionDeniesAlwaysRecorded verifica que as negações são registadas mesmo sem amostragem de permissões
+func TestPermissionDecisionDeniesAlwaysRecorded(t *testing.T) {
+	repo := &fakePermissionDecisionRepository{}
+	service := impl.NewPermissionDecisionAuditService(repo, impl.PermissionDecisionAuditConfig{AllowSampleRate: 0, BatchSize: 100})
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	for i := 0; i < 10; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles", true))
+	}
+	service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles/1", false))
+	require.NoError(t, service.Flush(ctx))
+
+	stored := repo.stored()
+	require.Len(t, stored, 1)
+	assert.False(t, stored[0].Allowed)
+	assert.Equal(t, 1.0, stored[0].SampleRate)
+	assert.NotEqual(t, uuid.Nil, stored[0].ID)
+	assert.False(t, stored[0].DecidedAt.IsZero())
+}
+
+// TestPermissionDecisionAllowsSampled verifica que as permissões amostradas guardam a taxa aplicada
+func TestPermissionDecisionAllowsSampled(t *testing.T) {
+	repo := &fakePermissionDecisionRepository{}
+	service := impl.NewPermissionDecisionAuditService(repo, impl.PermissionDecisionAuditConfig{AllowSampleRate: 1, BatchSize: 100})
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	for i := 0; i < 5; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles", true))
+	}
+	require.NoError(t, service.Flush(ctx))
+
+	stored := repo.stored()
+	require.Len(t, stored, 5)
+	for _, decision := range stored {
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 1.0, decision.SampleRate)
+	}
+}
+
+// TestPermissionDecisionBatching verifica a gravação em lotes e a antecipação quando um lote fica completo
+func TestPermissionDecisionBatching(t *testing.T) {
+	repo := &fakePermissionDecisionRepository{}
+	service := impl.NewPermissionDecisionAuditService(repo, impl.PermissionDecisionAuditConfig{AllowSampleRate: 1, BatchSize: 4})
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	for i := 0; i < 4; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles", i%2 == 0))
+	}
+	require.Eventually(t, func() bool { return len(repo.stored()) == 4 }, time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles", false))
+	}
+	require.NoError(t, service.Flush(ctx))
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Equal(t, []int{4, 3}, repo.batches)
+}
+
+// TestPermissionDecisionRequeuedAfterFailure verifica que um lote não gravado é retomado na gravação seguinte
+func TestPermissionDecisionRequeuedAfterFailure(t *testing.T) {
+	repo := &fakePermissionDecisionRepository{}
+	service := impl.NewPermissionDecisionAuditService(repo, impl.PermissionDecisionAuditConfig{AllowSampleRate: 1, BatchSize: 10})
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	repo.failWith(errors.New("armazenamento indisponível"))
+	for i := 0; i < 6; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles", true))
+	}
+	for i := 0; i < 3; i++ {
+		service.Record(ctx, newDecision(tenantID, userID, "/api/v1/roles/1", false))
+	}
+	require.Error(t, service.Flush(ctx))
+	assert.Empty(t, repo.stored())
+
+	repo.failWith(nil)
+	require.NoError(t, service.Flush(ctx))
+
+	allowed, denied := countAllowed(repo.stored())
+	assert.Equal(t, 6, allowed)
+	assert.Equal(t, 3, denied)
+}
+
+// TestPermissionDecisionListValidatesFilter verifica a rejeição de intervalos e padrões de recurso inválidos
+func TestPermissionDecisionListValidatesFilter(t *testing.T) {
+	repo := &fakePermissionDecisionRepository{}
+	service := impl.NewPermissionDecisionAuditService(repo, impl.DefaultPermissionDecisionAuditConfig())
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	_, err := service.ListDecisions(ctx, tenantID, model.PermissionDecisionFilter{From: &from, To: &to})
+	assert.ErrorIs(t, err, application.ErrInvalidPermissionDecisionFilter)
+
+	_, err = service.ListDecisions(ctx, tenantID, model.PermissionDecisionFilter{Resource: "/api/*/roles"})
+	assert.ErrorIs(t, err, application.ErrInvalidPermissionDecisionFilter)
+
+	to = from.Add(time.Hour)
+	userID := uuid.New()
+	_, err = service.ListDecisions(ctx, tenantID, model.PermissionDecisionFilter{
+		UserID:   &userID,
+		Resource: "/api/v1/roles/*",
+		From:     &from,
+		To:       &to,
+	})
+	require.NoError(t, err)
+
+	prefix, isPrefix := repo.lastFilter.ResourcePrefix()
+	assert.True(t, isPrefix)
+	assert.Equal(t, "/api/v1/roles/", prefix)
+}
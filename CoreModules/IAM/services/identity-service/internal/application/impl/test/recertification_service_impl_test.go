@@ -0,0 +1,609 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as campanhas de recertificação (RecertificationService).
+ * Valida a geração dos itens a partir do âmbito, a escolha dos revisores, as decisões e a
+ * revogação das atribuições, o escalamento e a revogação automática no prazo, e a atestação final.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeRecertificationRepository é um RecertificationRepository em memória
+type fakeRecertificationRepository struct {
+	mu        sync.Mutex
+	campaigns map[uuid.UUID]*model.RecertificationCampaign
+	items     map[uuid.UUID]*model.RecertificationItem
+}
+
+func newFakeRecertificationRepository() *fakeRecertificationRepository {
+	return &fakeRecertificationRepository{
+		campaigns: make(map[uuid.UUID]*model.RecertificationCampaign),
+		items:     make(map[uuid.UUID]*model.RecertificationItem),
+	}
+}
+
+func (r *fakeRecertificationRepository) CreateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, items []*model.RecertificationItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *campaign
+	r.campaigns[campaign.ID] = &copied
+	for _, item := range items {
+		copiedItem := *item
+		r.items[item.ID] = &copiedItem
+	}
+	return nil
+}
+
+func (r *fakeRecertificationRepository) GetCampaign(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationCampaign, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	campaign, ok := r.campaigns[campaignID]
+	if !ok || campaign.TenantID != tenantID {
+		return nil, model.ErrRecertificationCampaignNotFound
+	}
+	copied := *campaign
+	return &copied, nil
+}
+
+func (r *fakeRecertificationRepository) ListCampaigns(ctx context.Context, tenantID uuid.UUID, status model.RecertificationCampaignStatus) ([]*model.RecertificationCampaign, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var campaigns []*model.RecertificationCampaign
+	for _, campaign := range r.campaigns {
+		if campaign.TenantID == tenantID && (status == "" || campaign.Status == status) {
+			copied := *campaign
+			campaigns = append(campaigns, &copied)
+		}
+	}
+	return campaigns, nil
+}
+
+func (r *fakeRecertificationRepository) UpdateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, from model.RecertificationCampaignStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.campaigns[campaign.ID]
+	if !ok || existing.Status != from {
+		return model.ErrRecertificationCampaignState
+	}
+	copied := *campaign
+	r.campaigns[campaign.ID] = &copied
+	return nil
+}
+
+func (r *fakeRecertificationRepository) ListDueCampaigns(ctx context.Context, now time.Time, limit int) ([]*model.RecertificationCampaign, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var campaigns []*model.RecertificationCampaign
+	for _, campaign := range r.campaigns {
+		due := campaign.Status == model.RecertificationCampaignActive &&
+			(!campaign.Deadline.After(now) || (!campaign.EscalateAt.After(now) && campaign.EscalatedAt == nil))
+		if due && len(campaigns) < limit {
+			copied := *campaign
+			campaigns = append(campaigns, &copied)
+		}
+	}
+	return campaigns, nil
+}
+
+func (r *fakeRecertificationRepository) GetItem(ctx context.Context, tenantID, itemID uuid.UUID) (*model.RecertificationItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok || item.TenantID != tenantID {
+		return nil, model.ErrRecertificationItemNotFound
+	}
+	copied := *item
+	return &copied, nil
+}
+
+func (r *fakeRecertificationRepository) ListItems(ctx context.Context, tenantID uuid.UUID, filter model.RecertificationItemFilter) ([]*model.RecertificationItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []*model.RecertificationItem
+	for _, item := range r.items {
+		if item.TenantID != tenantID ||
+			(filter.CampaignID != nil && item.CampaignID != *filter.CampaignID) ||
+			(filter.ReviewerID != nil && item.ReviewerID != *filter.ReviewerID) ||
+			(filter.Status != "" && item.Status != filter.Status) {
+			continue
+		}
+		copied := *item
+		items = append(items, &copied)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].RoleCode != items[j].RoleCode {
+			return items[i].RoleCode < items[j].RoleCode
+		}
+		return items[i].UserID.String() < items[j].UserID.String()
+	})
+	return items, nil
+}
+
+func (r *fakeRecertificationRepository) UpdateItem(ctx context.Context, item *model.RecertificationItem, from model.RecertificationItemStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.items[item.ID]
+	if !ok || existing.Status != from {
+		return model.ErrRecertificationItemDecided
+	}
+	copied := *item
+	r.items[item.ID] = &copied
+	return nil
+}
+
+func (r *fakeRecertificationRepository) CountPendingItems(ctx context.Context, tenantID, campaignID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := 0
+	for _, item := range r.items {
+		if item.TenantID == tenantID && item.CampaignID == campaignID && item.Status == model.RecertificationItemPending {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeRecertificationRepository) ListPendingRevocations(ctx context.Context, limit int) ([]*model.RecertificationItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []*model.RecertificationItem
+	for _, item := range r.items {
+		if item.NeedsRevocation() && len(items) < limit {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+	return items, nil
+}
+
+// setCampaign altera diretamente a campanha gravada, simulando a passagem do tempo
+func (r *fakeRecertificationRepository) setCampaign(campaignID uuid.UUID, change func(*model.RecertificationCampaign)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change(r.campaigns[campaignID])
+}
+
+func (r *fakeRecertificationRepository) campaign(campaignID uuid.UUID) model.RecertificationCampaign {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.campaigns[campaignID]
+}
+
+// fakeRecertificationRoleService é um RoleService em memória com as operações usadas pela recertificação
+type fakeRecertificationRoleService struct {
+	application.RoleService
+	mu          sync.Mutex
+	roles       map[uuid.UUID]*model.Role
+	assignments map[uuid.UUID][]uuid.UUID
+	revokeErr   error
+	revoked     []uuid.UUID
+}
+
+func (f *fakeRecertificationRoleService) GetRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error) {
+	role, ok := f.roles[roleID]
+	if !ok {
+		return nil, application.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (f *fakeRecertificationRoleService) GetRoleUsers(ctx context.Context, tenantID, roleID uuid.UUID, activeOnly bool, pagination application.Pagination) ([]application.UserRoleDetail, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := f.assignments[roleID]
+	start := (pagination.Page - 1) * pagination.PageSize
+	if start >= len(users) {
+		return nil, int64(len(users)), nil
+	}
+	end := start + pagination.PageSize
+	if end > len(users) {
+		end = len(users)
+	}
+
+	details := make([]application.UserRoleDetail, 0, end-start)
+	for _, userID := range users[start:end] {
+		details = append(details, application.UserRoleDetail{UserID: userID, AssignedAt: recertificationNow.Add(-90 * 24 * time.Hour)})
+	}
+	return details, int64(len(users)), nil
+}
+
+func (f *fakeRecertificationRoleService) RevokeUserFromRole(ctx context.Context, tenantID, roleID, userID, revokedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.revokeErr != nil {
+		return f.revokeErr
+	}
+	for i, assigned := range f.assignments[roleID] {
+		if assigned == userID {
+			f.assignments[roleID] = append(f.assignments[roleID][:i], f.assignments[roleID][i+1:]...)
+			f.revoked = append(f.revoked, userID)
+			return nil
+		}
+	}
+	return application.ErrUserNotAssigned
+}
+
+func (f *fakeRecertificationRoleService) setRevokeErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.revokeErr = err
+}
+
+// fakeRecertificationApprovers expõe os aprovadores registados nos pedidos de acesso
+type fakeRecertificationApprovers struct {
+	application.AccessRequestService
+	approvers []*model.AccessRequestApprover
+}
+
+func (f *fakeRecertificationApprovers) ListApprovers(ctx context.Context, tenantID uuid.UUID) ([]*model.AccessRequestApprover, error) {
+	return f.approvers, nil
+}
+
+var recertificationNow = time.Now().UTC()
+
+type recertificationFixture struct {
+	service    application.RecertificationService
+	repo       *fakeRecertificationRepository
+	roles      *fakeRecertificationRoleService
+	publisher  *recordingPublisher
+	tenantID   uuid.UUID
+	roleID     uuid.UUID
+	otherRole  uuid.UUID
+	owner      uuid.UUID
+	reviewer   uuid.UUID
+	escalation uuid.UUID
+	users      []uuid.UUID
+}
+
+// newRecertificationFixture cria duas funções com três titulares cada; a primeira tem dono
+func newRecertificationFixture() *recertificationFixture {
+	f := &recertificationFixture{
+		repo:       newFakeRecertificationRepository(),
+		publisher:  &recordingPublisher{},
+		tenantID:   uuid.New(),
+		roleID:     uuid.New(),
+		otherRole:  uuid.New(),
+		owner:      uuid.New(),
+		reviewer:   uuid.New(),
+		escalation: uuid.New(),
+		users:      []uuid.UUID{uuid.New(), uuid.New(), uuid.New()},
+	}
+	f.roles = &fakeRecertificationRoleService{
+		roles: map[uuid.UUID]*model.Role{
+			f.roleID:    {ID: f.roleID, Code: "finance-approver", Name: "Aprovador financeiro"},
+			f.otherRole: {ID: f.otherRole, Code: "support-agent", Name: "Agente de suporte"},
+		},
+		assignments: map[uuid.UUID][]uuid.UUID{
+			f.roleID:    append([]uuid.UUID{}, f.users...),
+			f.otherRole: append([]uuid.UUID{}, f.users...),
+		},
+	}
+	approvers := &fakeRecertificationApprovers{approvers: []*model.AccessRequestApprover{
+		{UserID: f.owner, Kind: model.AccessApproverKindRoleOwner, RoleID: &f.roleID},
+		{UserID: uuid.New(), Kind: model.AccessApproverKindDelegatedAdmin},
+	}}
+	f.service = impl.NewRecertificationService(f.repo, f.roles, approvers, f.publisher, impl.DefaultRecertificationConfig())
+	return f
+}
+
+func (f *recertificationFixture) createCampaign(t *testing.T, userIDs ...uuid.UUID) *model.RecertificationCampaign {
+	t.Helper()
+
+	campaign, err := f.service.CreateCampaign(context.Background(), &application.CreateRecertificationCampaignRequest{
+		TenantID:             f.tenantID,
+		Name:                 "Revisão trimestral",
+		RoleIDs:              []uuid.UUID{f.roleID, f.otherRole},
+		UserIDs:              userIDs,
+		DefaultReviewerID:    f.reviewer,
+		EscalationReviewerID: f.escalation,
+		Deadline:             recertificationNow.Add(14 * 24 * time.Hour),
+		ActorID:              uuid.New(),
+	})
+	require.NoError(t, err)
+	return campaign
+}
+
+func (f *recertificationFixture) items(t *testing.T, campaignID uuid.UUID, status model.RecertificationItemStatus) []*model.RecertificationItem {
+	t.Helper()
+
+	items, err := f.service.ListItems(context.Background(), f.tenantID, campaignID, model.RecertificationItemFilter{Status: status})
+	require.NoError(t, err)
+	return items
+}
+
+func TestRecertificationService_GeneratesItemsForScope(t *testing.T) {
+	f := newRecertificationFixture()
+
+	campaign := f.createCampaign(t, f.users[0], f.users[1])
+	assert.Equal(t, model.RecertificationCampaignActive, campaign.Status)
+	assert.Equal(t, 4, campaign.ItemCount)
+	assert.True(t, campaign.EscalateAt.Before(campaign.Deadline))
+
+	items := f.items(t, campaign.ID, "")
+	require.Len(t, items, 4)
+	for _, item := range items {
+		assert.NotEqual(t, f.users[2], item.UserID, "usuário fora da população")
+		if item.RoleID == f.roleID {
+			assert.Equal(t, f.owner, item.ReviewerID, "itens das funções com dono vão para o dono")
+		} else {
+			assert.Equal(t, f.reviewer, item.ReviewerID)
+		}
+	}
+
+	var notified []uuid.UUID
+	for _, evt := range f.publisher.published() {
+		review, ok := evt.(*event.RecertificationReviewEvent)
+		require.True(t, ok)
+		assert.Equal(t, event.TopicRecertificationReviewRequested, review.GetType())
+		assert.Equal(t, 2, review.Items)
+		notified = append(notified, review.ReviewerID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{f.owner, f.reviewer}, notified)
+}
+
+func TestRecertificationService_ValidatesCampaign(t *testing.T) {
+	f := newRecertificationFixture()
+	ctx := context.Background()
+
+	valid := func() *application.CreateRecertificationCampaignRequest {
+		return &application.CreateRecertificationCampaignRequest{
+			TenantID:             f.tenantID,
+			Name:                 "Revisão",
+			RoleIDs:              []uuid.UUID{f.roleID},
+			DefaultReviewerID:    f.reviewer,
+			EscalationReviewerID: f.escalation,
+			Deadline:             recertificationNow.Add(7 * 24 * time.Hour),
+		}
+	}
+
+	req := valid()
+	req.EscalationReviewerID = f.reviewer
+	_, err := f.service.CreateCampaign(ctx, req)
+	assert.ErrorIs(t, err, application.ErrInvalidRecertificationCampaign)
+
+	req = valid()
+	req.Deadline = recertificationNow.Add(-time.Hour)
+	_, err = f.service.CreateCampaign(ctx, req)
+	assert.ErrorIs(t, err, application.ErrInvalidRecertificationCampaign)
+
+	req = valid()
+	req.RoleIDs = []uuid.UUID{uuid.New()}
+	_, err = f.service.CreateCampaign(ctx, req)
+	assert.ErrorIs(t, err, application.ErrInvalidRecertificationCampaign)
+
+	req = valid()
+	req.UserIDs = []uuid.UUID{uuid.New()}
+	_, err = f.service.CreateCampaign(ctx, req)
+	assert.ErrorIs(t, err, application.ErrRecertificationEmptyScope)
+}
+
+func TestRecertificationService_OwnerNeverReviewsOwnAccess(t *testing.T) {
+	f := newRecertificationFixture()
+	f.roles.assignments[f.roleID] = append(f.roles.assignments[f.roleID], f.owner)
+
+	campaign := f.createCampaign(t, f.owner)
+	items := f.items(t, campaign.ID, "")
+	require.Len(t, items, 1)
+	assert.Equal(t, f.reviewer, items[0].ReviewerID)
+}
+
+func TestRecertificationService_DecisionsAndCompletion(t *testing.T) {
+	f := newRecertificationFixture()
+	ctx := context.Background()
+	campaign := f.createCampaign(t, f.users[0])
+
+	items := f.items(t, campaign.ID, model.RecertificationItemPending)
+	require.Len(t, items, 2)
+	ownerItem, otherItem := items[0], items[1]
+	require.Equal(t, f.roleID, ownerItem.RoleID)
+
+	// Só o revisor atribuído decide, e a revogação exige comentário
+	_, err := f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: ownerItem.ID, ReviewerID: f.reviewer, Decision: model.RecertificationDecisionApprove,
+	})
+	assert.ErrorIs(t, err, application.ErrRecertificationNotReviewer)
+	_, err = f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: ownerItem.ID, ReviewerID: f.users[0], Decision: model.RecertificationDecisionApprove,
+	})
+	assert.ErrorIs(t, err, application.ErrRecertificationSelfReview)
+	_, err = f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: ownerItem.ID, ReviewerID: f.owner, Decision: model.RecertificationDecisionRevoke,
+	})
+	assert.ErrorIs(t, err, application.ErrInvalidRecertificationDecision)
+
+	revoked, err := f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: ownerItem.ID, ReviewerID: f.owner, Decision: model.RecertificationDecisionRevoke,
+		Comment: "já não pertence à equipa financeira",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.RecertificationItemRevoked, revoked.Status)
+	assert.NotNil(t, revoked.RevokedAt)
+	assert.Equal(t, []uuid.UUID{f.users[0]}, f.roles.revoked)
+	assert.Equal(t, model.RecertificationCampaignActive, f.repo.campaign(campaign.ID).Status)
+
+	_, err = f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: ownerItem.ID, ReviewerID: f.owner, Decision: model.RecertificationDecisionApprove,
+	})
+	assert.ErrorIs(t, err, application.ErrRecertificationItemDecided)
+
+	reviews, err := f.service.ListPendingReviews(ctx, f.tenantID, f.reviewer)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+
+	// A última decisão conclui a campanha e publica a atestação
+	_, err = f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: otherItem.ID, ReviewerID: f.reviewer, Decision: model.RecertificationDecisionApprove,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.RecertificationCampaignCompleted, f.repo.campaign(campaign.ID).Status)
+
+	events := f.publisher.published()
+	completed, ok := events[len(events)-1].(*event.RecertificationCampaignCompletedEvent)
+	require.True(t, ok)
+	assert.Equal(t, 1, completed.Summary.Approved)
+	assert.Equal(t, 1, completed.Summary.Revoked)
+	assert.NotEmpty(t, completed.Digest)
+}
+
+func TestRecertificationService_EscalatesAndAutoRevokes(t *testing.T) {
+	f := newRecertificationFixture()
+	ctx := context.Background()
+	campaign := f.createCampaign(t)
+
+	items := f.items(t, campaign.ID, model.RecertificationItemPending)
+	require.Len(t, items, 6)
+	_, err := f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: items[0].ID, ReviewerID: items[0].ReviewerID, Decision: model.RecertificationDecisionApprove,
+	})
+	require.NoError(t, err)
+
+	// Ainda antes da data de escalamento, nada acontece
+	result, err := f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Escalated)
+
+	f.repo.setCampaign(campaign.ID, func(c *model.RecertificationCampaign) {
+		c.EscalateAt = recertificationNow.Add(-time.Minute)
+	})
+	result, err = f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Escalated)
+	assert.NotNil(t, f.repo.campaign(campaign.ID).EscalatedAt)
+
+	escalated, err := f.service.ListPendingReviews(ctx, f.tenantID, f.escalation)
+	require.NoError(t, err)
+	assert.Len(t, escalated, 5)
+
+	events := f.publisher.published()
+	last, ok := events[len(events)-1].(*event.RecertificationReviewEvent)
+	require.True(t, ok)
+	assert.Equal(t, event.TopicRecertificationReviewEscalated, last.GetType())
+	assert.Equal(t, f.escalation, last.ReviewerID)
+
+	// Uma segunda passagem não volta a escalar
+	result, err = f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Escalated)
+
+	// No fim do prazo, os itens por decidir são revogados e a campanha é concluída
+	f.roles.setRevokeErr(errors.New("base de dados indisponível"))
+	f.repo.setCampaign(campaign.ID, func(c *model.RecertificationCampaign) {
+		c.Deadline = recertificationNow.Add(-time.Second)
+	})
+	_, err = f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID: f.tenantID, ItemID: items[1].ID, ReviewerID: f.escalation, Decision: model.RecertificationDecisionApprove,
+	})
+	assert.ErrorIs(t, err, application.ErrRecertificationCampaignState)
+
+	result, err = f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.AutoRevoked)
+	assert.Equal(t, 1, result.CampaignsCompleted)
+	assert.Equal(t, model.RecertificationCampaignCompleted, f.repo.campaign(campaign.ID).Status)
+
+	for _, item := range f.items(t, campaign.ID, model.RecertificationItemRevoked) {
+		assert.True(t, item.AutoRevoked)
+		assert.Nil(t, item.DecidedBy)
+		assert.NotEmpty(t, item.RevocationError)
+	}
+
+	// As revogações que falharam são repetidas nas execuções seguintes
+	f.roles.setRevokeErr(nil)
+	result, err = f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.RevocationsRetried)
+	assert.Len(t, f.roles.revoked, 5)
+	for _, item := range f.items(t, campaign.ID, model.RecertificationItemRevoked) {
+		assert.NotNil(t, item.RevokedAt)
+		assert.Empty(t, item.RevocationError)
+	}
+}
+
+func TestRecertificationService_Attestation(t *testing.T) {
+	f := newRecertificationFixture()
+	ctx := context.Background()
+	campaign := f.createCampaign(t, f.users[0])
+
+	_, err := f.service.GetAttestation(ctx, f.tenantID, campaign.ID)
+	assert.ErrorIs(t, err, application.ErrRecertificationCampaignState)
+
+	for _, item := range f.items(t, campaign.ID, model.RecertificationItemPending) {
+		_, err := f.service.Decide(ctx, &application.RecertificationDecisionRequest{
+			TenantID: f.tenantID, ItemID: item.ID, ReviewerID: item.ReviewerID, Decision: model.RecertificationDecisionApprove,
+		})
+		require.NoError(t, err)
+	}
+
+	attestation, err := f.service.GetAttestation(ctx, f.tenantID, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attestation.Summary.Total)
+	assert.Equal(t, 2, attestation.Summary.Approved)
+	assert.Len(t, attestation.Reviewers, 2)
+	assert.Len(t, attestation.Items, 2)
+
+	digest, err := attestation.ComputeDigest()
+	require.NoError(t, err)
+	assert.Equal(t, attestation.Digest, digest)
+
+	attestation.Items[0].Status = model.RecertificationItemRevoked
+	tampered, err := attestation.ComputeDigest()
+	require.NoError(t, err)
+	assert.NotEqual(t, attestation.Digest, tampered)
+}
+
+func TestRecertificationService_CancelCampaign(t *testing.T) {
+	f := newRecertificationFixture()
+	ctx := context.Background()
+	campaign := f.createCampaign(t)
+
+	_, err := f.service.CancelCampaign(ctx, f.tenantID, campaign.ID, uuid.New(), "")
+	assert.ErrorIs(t, err, application.ErrInvalidRecertificationCampaign)
+
+	cancelled, err := f.service.CancelCampaign(ctx, f.tenantID, campaign.ID, uuid.New(), "âmbito incorreto")
+	require.NoError(t, err)
+	assert.Equal(t, model.RecertificationCampaignCancelled, cancelled.Status)
+
+	reviews, err := f.service.ListPendingReviews(ctx, f.tenantID, f.reviewer)
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+
+	// Uma campanha cancelada deixa de ser processada pelas execuções agendadas
+	f.repo.setCampaign(campaign.ID, func(c *model.RecertificationCampaign) {
+		c.Deadline = recertificationNow.Add(-time.Second)
+	})
+	result, err := f.service.RunScheduledEscalations(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.AutoRevoked)
+	assert.Empty(t, f.roles.revoked)
+}
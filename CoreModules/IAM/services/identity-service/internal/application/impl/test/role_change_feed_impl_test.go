@@ -0,0 +1,230 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o fluxo em tempo real das alterações de funções (RoleChangeFeed).
+ * Valida a assinatura dos tópicos no barramento, a filtragem por tenant, função e usuário,
+ * as quotas de subscriptions e o encerramento das subscriptions que não acompanham o fluxo.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeEventBus é um EventBus em memória que entrega os eventos de forma síncrona
+type fakeEventBus struct {
+	mu       sync.Mutex
+	handlers map[string][]func(ctx context.Context, evt event.Event) error
+}
+
+func (b *fakeEventBus) Publish(ctx context.Context, eventType string, evt event.Event) error {
+	b.mu.Lock()
+	handlers := append([]func(ctx context.Context, evt event.Event) error(nil), b.handlers[eventType]...)
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *fakeEventBus) Subscribe(eventType string, handler func(ctx context.Context, evt event.Event) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.handlers == nil {
+		b.handlers = make(map[string][]func(ctx context.Context, evt event.Event) error)
+	}
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+	return nil
+}
+
+func (b *fakeEventBus) Unsubscribe(eventType string, handler func(ctx context.Context, evt event.Event) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.handlers, eventType)
+	return nil
+}
+
+// receiveRoleChange espera por uma notificação ou falha o teste
+func receiveRoleChange(t *testing.T, events <-chan *model.RoleChangeNotification) *model.RoleChangeNotification {
+	t.Helper()
+	select {
+	case notification, ok := <-events:
+		require.True(t, ok, "a subscription não deve estar fechada")
+		return notification
+	case <-time.After(time.Second):
+		t.Fatal("notificação não recebida")
+		return nil
+	}
+}
+
+// assertNoRoleChange verifica que não há notificações pendentes
+func assertNoRoleChange(t *testing.T, events <-chan *model.RoleChangeNotification) {
+	t.Helper()
+	select {
+	case notification := <-events:
+		t.Fatalf("notificação inesperada: %+v", notification)
+	default:
+	}
+}
+
+func TestRoleChangeFeed_DeliversFilteredByTenant(t *testing.T) {
+	bus := &fakeEventBus{}
+	feed := impl.NewRoleChangeFeed(impl.DefaultRoleChangeFeedConfig())
+	require.NoError(t, feed.Attach(bus))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantID, otherTenantID := uuid.New(), uuid.New()
+	subscriber := model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}
+	events, err := feed.Subscribe(ctx, subscriber, model.RoleChangeFilter{TenantID: tenantID})
+	require.NoError(t, err)
+
+	roleID, actorID := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	require.NoError(t, bus.Publish(ctx, event.TopicRoleCreated, &event.RoleCreatedEvent{
+		TenantID: otherTenantID, RoleID: uuid.New(), Code: "OTHER", EventTime: now,
+	}))
+	require.NoError(t, bus.Publish(ctx, event.TopicRoleCreated, &event.RoleCreatedEvent{
+		TenantID: tenantID, RoleID: roleID, Code: "AUDITOR", Name: "Auditor", IsActive: true, CreatedBy: actorID, EventTime: now,
+	}))
+
+	notification := receiveRoleChange(t, events)
+	assert.Equal(t, model.RoleChangeCreated, notification.Type)
+	assert.Equal(t, tenantID, notification.TenantID)
+	assert.Equal(t, roleID, notification.RoleID)
+	assert.Equal(t, "AUDITOR", notification.RoleCode)
+	require.NotNil(t, notification.IsActive)
+	assert.True(t, *notification.IsActive)
+	require.NotNil(t, notification.ActorID)
+	assert.Equal(t, actorID, *notification.ActorID)
+	assertNoRoleChange(t, events)
+
+	require.NoError(t, bus.Publish(ctx, event.TopicRoleHardDeleted, &event.RoleHardDeletedEvent{
+		TenantID: tenantID, RoleID: roleID, Code: "AUDITOR", EventTime: now,
+	}))
+	notification = receiveRoleChange(t, events)
+	assert.Equal(t, model.RoleChangeDeleted, notification.Type)
+	assert.True(t, notification.HardDeleted)
+}
+
+func TestRoleChangeFeed_AssignmentFilters(t *testing.T) {
+	feed := impl.NewRoleChangeFeed(impl.DefaultRoleChangeFeedConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantID, roleID, userID := uuid.New(), uuid.New(), uuid.New()
+	subscriber := model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}
+	events, err := feed.Subscribe(ctx, subscriber, model.RoleChangeFilter{
+		TenantID: tenantID,
+		UserID:   &userID,
+		Types:    []model.RoleChangeType{model.RoleChangeUsersAssigned, model.RoleChangeUsersRevoked},
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	// Alterações da função e atribuições a outros usuários não satisfazem o filtro
+	require.NoError(t, feed.HandleEvent(ctx, &event.RoleUpdatedEvent{TenantID: tenantID, RoleID: roleID, EventTime: now}))
+	require.NoError(t, feed.HandleEvent(ctx, &event.RoleAssignedToUsersEvent{
+		TenantID: tenantID, RoleID: roleID, UserIDs: []uuid.UUID{uuid.New()}, EventTime: now,
+	}))
+	require.NoError(t, feed.HandleEvent(ctx, &event.RoleRevokedFromUsersEvent{
+		TenantID: tenantID, RoleID: roleID, RoleCode: "OPERATOR", UserIDs: []uuid.UUID{uuid.New(), userID}, EventTime: now,
+	}))
+
+	notification := receiveRoleChange(t, events)
+	assert.Equal(t, model.RoleChangeUsersRevoked, notification.Type)
+	assert.True(t, notification.Type.IsAssignment())
+	assert.Contains(t, notification.UserIDs, userID)
+	assertNoRoleChange(t, events)
+}
+
+func TestRoleChangeFeed_SubscriptionLimits(t *testing.T) {
+	feed := impl.NewRoleChangeFeed(impl.RoleChangeFeedConfig{MaxSubscriptionsPerTenant: 3, MaxSubscriptionsPerUser: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantID := uuid.New()
+	user := model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}
+	filter := model.RoleChangeFilter{TenantID: tenantID}
+
+	userCtx, cancelUser := context.WithCancel(ctx)
+	_, err := feed.Subscribe(userCtx, user, filter)
+	require.NoError(t, err)
+	_, err = feed.Subscribe(userCtx, user, filter)
+	require.NoError(t, err)
+	_, err = feed.Subscribe(ctx, user, filter)
+	assert.True(t, errors.Is(err, model.ErrRoleChangeSubscriptionLimit), "quota por usuário")
+
+	_, err = feed.Subscribe(ctx, model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}, filter)
+	require.NoError(t, err)
+	_, err = feed.Subscribe(ctx, model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}, filter)
+	assert.True(t, errors.Is(err, model.ErrRoleChangeSubscriptionLimit), "quota por tenant")
+
+	// Terminar as subscriptions do usuário liberta as quotas
+	cancelUser()
+	require.Eventually(t, func() bool { return feed.ActiveSubscriptions() == 1 }, time.Second, 10*time.Millisecond)
+	_, err = feed.Subscribe(ctx, user, filter)
+	assert.NoError(t, err)
+
+	_, err = feed.Subscribe(ctx, model.RoleChangeSubscriber{UserID: uuid.New()}, filter)
+	assert.ErrorIs(t, err, model.ErrInvalidTenantID)
+}
+
+func TestRoleChangeFeed_ClosesLaggingSubscription(t *testing.T) {
+	feed := impl.NewRoleChangeFeed(impl.RoleChangeFeedConfig{BufferSize: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantID := uuid.New()
+	events, err := feed.Subscribe(ctx, model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}, model.RoleChangeFilter{TenantID: tenantID})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, feed.HandleEvent(ctx, &event.RoleUpdatedEvent{TenantID: tenantID, RoleID: uuid.New(), EventTime: time.Now()}))
+	}
+
+	// A notificação em fila é entregue e o canal é fechado em seguida
+	receiveRoleChange(t, events)
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Equal(t, 0, feed.ActiveSubscriptions())
+}
+
+func TestRoleChangeFeed_Close(t *testing.T) {
+	bus := &fakeEventBus{}
+	feed := impl.NewRoleChangeFeed(impl.DefaultRoleChangeFeedConfig())
+	require.NoError(t, feed.Attach(bus))
+
+	tenantID := uuid.New()
+	subscriber := model.RoleChangeSubscriber{TenantID: tenantID, UserID: uuid.New()}
+	events, err := feed.Subscribe(context.Background(), subscriber, model.RoleChangeFilter{TenantID: tenantID})
+	require.NoError(t, err)
+
+	require.NoError(t, feed.Close())
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Empty(t, bus.handlers)
+
+	_, err = feed.Subscribe(context.Background(), subscriber, model.RoleChangeFilter{TenantID: tenantID})
+	assert.ErrorIs(t, err, model.ErrRoleChangeFeedClosed)
+}
@@ -0,0 +1,291 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de histórico de funções (RoleHistoryService).
+ * Valida a reconstrução do estado num instante, os snapshots e a comparação entre instantes.
+ */
+
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeRoleEventStore é um RoleEventStore em memória
+type fakeRoleEventStore struct {
+	mu        sync.Mutex
+	events    []*model.RoleHistoryEvent
+	snapshots []*model.RoleSnapshot
+
+	// conflicts simula escritas concorrentes: as próximas N chamadas a Append falham com conflito
+	conflicts int
+	loaded    int
+
+	// competingWrites grava, em cada conflito simulado, o evento concorrente que o causou
+	competingWrites bool
+}
+
+func (s *fakeRoleEventStore) Append(ctx context.Context, events ...*model.RoleHistoryEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conflicts > 0 {
+		s.conflicts--
+		if s.competingWrites {
+			s.events = append(s.events, &model.RoleHistoryEvent{
+				ID:         uuid.New(),
+				TenantID:   events[0].TenantID,
+				RoleID:     events[0].RoleID,
+				Type:       model.RoleHistoryUpdated,
+				Version:    events[0].Version,
+				OccurredAt: time.Now(),
+			})
+		}
+		return model.ErrRoleHistoryVersionConflict
+	}
+	for _, evt := range events {
+		copied := *evt
+		s.events = append(s.events, &copied)
+	}
+	return nil
+}
+
+func (s *fakeRoleEventStore) LastVersion(ctx context.Context, tenantID, roleID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last int64
+	for _, evt := range s.events {
+		if evt.TenantID == tenantID && evt.RoleID == roleID && evt.Version > last {
+			last = evt.Version
+		}
+	}
+	return last, nil
+}
+
+func (s *fakeRoleEventStore) LoadEvents(ctx context.Context, tenantID, roleID uuid.UUID, afterVersion int64, until time.Time) ([]*model.RoleHistoryEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []*model.RoleHistoryEvent
+	for _, evt := range s.events {
+		if evt.TenantID == tenantID && evt.RoleID == roleID && evt.Version > afterVersion && !evt.OccurredAt.After(until) {
+			events = append(events, evt)
+		}
+	}
+	s.loaded += len(events)
+	return events, nil
+}
+
+func (s *fakeRoleEventStore) SaveSnapshot(ctx context.Context, snapshot *model.RoleSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+func (s *fakeRoleEventStore) LatestSnapshot(ctx context.Context, tenantID, roleID uuid.UUID, until time.Time) (*model.RoleSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *model.RoleSnapshot
+	for _, snapshot := range s.snapshots {
+		if snapshot.TenantID == tenantID && snapshot.RoleID == roleID && !snapshot.TakenAt.After(until) &&
+			(latest == nil || snapshot.Version > latest.Version) {
+			latest = snapshot
+		}
+	}
+	return latest, nil
+}
+
+// roleHistoryFixture regista eventos de uma função e guarda instantes entre eles
+type roleHistoryFixture struct {
+	t        *testing.T
+	store    *fakeRoleEventStore
+	service  application.RoleHistoryService
+	tenantID uuid.UUID
+	roleID   uuid.UUID
+}
+
+func newRoleHistoryFixture(t *testing.T, snapshotInterval int) *roleHistoryFixture {
+	store := &fakeRoleEventStore{}
+	return &roleHistoryFixture{
+		t:        t,
+		store:    store,
+		service:  impl.NewRoleHistoryService(store, snapshotInterval),
+		tenantID: uuid.New(),
+		roleID:   uuid.New(),
+	}
+}
+
+// record regista um evento e retorna um instante posterior a ele
+func (f *roleHistoryFixture) record(eventType model.RoleHistoryEventType, payload model.RoleHistoryPayload) time.Time {
+	require.NoError(f.t, f.service.Record(context.Background(), &model.RoleHistoryEvent{
+		TenantID: f.tenantID,
+		RoleID:   f.roleID,
+		Type:     eventType,
+		Payload:  payload,
+	}))
+	time.Sleep(2 * time.Millisecond)
+	checkpoint := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	return checkpoint
+}
+
+func stringPtr(s string) *string { return &s }
+
+// TestRoleHistory_GetRoleAtReconstructsPastState verifica a reconstrução do estado em instantes anteriores
+func TestRoleHistory_GetRoleAtReconstructsPastState(t *testing.T) {
+	f := newRoleHistoryFixture(t, 0)
+	permission := uuid.New()
+
+	beforeCreation := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	afterCreation := f.record(model.RoleHistoryCreated, model.RoleHistoryPayload{
+		Code: stringPtr("AUDITOR"),
+		Name: stringPtr("Auditor"),
+	})
+	afterAssign := f.record(model.RoleHistoryPermissionsAssigned, model.RoleHistoryPayload{PermissionIDs: []uuid.UUID{permission}})
+	afterRename := f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("Auditor Externo")})
+
+	_, err := f.service.GetRoleAt(context.Background(), f.tenantID, f.roleID, beforeCreation)
+	assert.ErrorIs(t, err, application.ErrRoleHistoryNotFound)
+
+	state, err := f.service.GetRoleAt(context.Background(), f.tenantID, f.roleID, afterCreation)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), state.Version)
+	assert.Equal(t, "Auditor", state.Name)
+	assert.Empty(t, state.PermissionIDs)
+
+	state, err = f.service.GetRoleAt(context.Background(), f.tenantID, f.roleID, afterAssign)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{permission}, state.PermissionIDs)
+	assert.Equal(t, "Auditor", state.Name)
+
+	state, err = f.service.GetRoleAt(context.Background(), f.tenantID, f.roleID, afterRename)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), state.Version)
+	assert.Equal(t, "AUDITOR", state.Code)
+	assert.Equal(t, "Auditor Externo", state.Name)
+
+	events, err := f.service.GetRoleHistory(context.Background(), f.tenantID, f.roleID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i, evt := range events {
+		assert.Equal(t, int64(i+1), evt.Version)
+	}
+}
+
+// TestRoleHistory_DiffRole verifica as diferenças entre dois instantes
+func TestRoleHistory_DiffRole(t *testing.T) {
+	f := newRoleHistoryFixture(t, 0)
+	kept, revoked, added := uuid.New(), uuid.New(), uuid.New()
+	parent := uuid.New()
+
+	f.record(model.RoleHistoryCreated, model.RoleHistoryPayload{
+		Code: stringPtr("OPERATOR"),
+		Name: stringPtr("Operador"),
+	})
+	from := f.record(model.RoleHistoryPermissionsAssigned, model.RoleHistoryPayload{PermissionIDs: []uuid.UUID{kept, revoked}})
+	f.record(model.RoleHistoryPermissionsRevoked, model.RoleHistoryPayload{PermissionIDs: []uuid.UUID{revoked}})
+	f.record(model.RoleHistoryPermissionsAssigned, model.RoleHistoryPayload{PermissionIDs: []uuid.UUID{added}})
+	f.record(model.RoleHistoryParentAssigned, model.RoleHistoryPayload{ParentID: &parent})
+	to := f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("Operador Sénior")})
+
+	diff, err := f.service.DiffRole(context.Background(), f.tenantID, f.roleID, from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), diff.FromVersion)
+	assert.Equal(t, int64(6), diff.ToVersion)
+	require.Len(t, diff.Changes, 1)
+	assert.Equal(t, "name", diff.Changes[0].Field)
+	assert.Equal(t, "Operador", diff.Changes[0].Before)
+	assert.Equal(t, "Operador Sénior", diff.Changes[0].After)
+	assert.Equal(t, []uuid.UUID{added}, diff.AddedPermissions)
+	assert.Equal(t, []uuid.UUID{revoked}, diff.RemovedPermissions)
+	assert.Equal(t, []uuid.UUID{parent}, diff.AddedParents)
+	assert.Empty(t, diff.RemovedParents)
+
+	_, err = f.service.DiffRole(context.Background(), f.tenantID, f.roleID, to, from)
+	assert.ErrorIs(t, err, application.ErrInvalidHistoryRange)
+}
+
+// TestRoleHistory_SnapshotsShortenReconstruction verifica que os snapshots evitam reaplicar o histórico
+func TestRoleHistory_SnapshotsShortenReconstruction(t *testing.T) {
+	f := newRoleHistoryFixture(t, 2)
+
+	f.record(model.RoleHistoryCreated, model.RoleHistoryPayload{Code: stringPtr("VIEWER"), Name: stringPtr("v1")})
+	f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("v2")})
+	f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("v3")})
+	f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("v4")})
+	last := f.record(model.RoleHistoryDeleted, model.RoleHistoryPayload{})
+
+	require.Len(t, f.store.snapshots, 2)
+	assert.Equal(t, int64(2), f.store.snapshots[0].Version)
+	assert.Equal(t, int64(4), f.store.snapshots[1].Version)
+	assert.Equal(t, "v4", f.store.snapshots[1].State.Name)
+
+	f.store.loaded = 0
+	state, err := f.service.GetRoleAt(context.Background(), f.tenantID, f.roleID, last)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.store.loaded, "apenas o evento posterior ao snapshot deve ser aplicado")
+	assert.Equal(t, int64(5), state.Version)
+	assert.Equal(t, "v4", state.Name)
+	assert.True(t, state.Deleted)
+}
+
+// TestRoleHistory_RecordRetriesOnVersionConflict verifica a nova tentativa após escritas concorrentes
+func TestRoleHistory_RecordRetriesOnVersionConflict(t *testing.T) {
+	f := newRoleHistoryFixture(t, 0)
+	f.record(model.RoleHistoryCreated, model.RoleHistoryPayload{Code: stringPtr("ADMIN")})
+
+	f.store.conflicts = 1
+	f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("Administrador")})
+
+	events, err := f.service.GetRoleHistory(context.Background(), f.tenantID, f.roleID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(2), events[1].Version)
+
+	f.store.conflicts = 5
+	err = f.service.Record(context.Background(), &model.RoleHistoryEvent{
+		TenantID: f.tenantID,
+		RoleID:   f.roleID,
+		Type:     model.RoleHistoryUpdated,
+	})
+	assert.ErrorIs(t, err, model.ErrRoleHistoryVersionConflict)
+}
+
+// TestRoleHistory_RecordStampsAfterCompetingWrite verifica que a versão gravada após um conflito
+// não fica com um instante anterior ao do evento concorrente que a precede
+func TestRoleHistory_RecordStampsAfterCompetingWrite(t *testing.T) {
+	f := newRoleHistoryFixture(t, 0)
+	f.record(model.RoleHistoryCreated, model.RoleHistoryPayload{Code: stringPtr("ADMIN")})
+
+	f.store.conflicts = 1
+	f.store.competingWrites = true
+	f.record(model.RoleHistoryUpdated, model.RoleHistoryPayload{Name: stringPtr("Administrador")})
+
+	events, err := f.service.GetRoleHistory(context.Background(), f.tenantID, f.roleID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	competing, recorded := events[1], events[2]
+	assert.Equal(t, int64(2), competing.Version)
+	assert.Equal(t, int64(3), recorded.Version)
+	assert.False(t, recorded.OccurredAt.Before(competing.OccurredAt),
+		"a versão seguinte não pode ter um instante anterior ao da versão que a precede")
+}
@@ -0,0 +1,198 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as políticas dos metadados das funções (RoleMetadataPolicyService).
+ * Valida a leitura das políticas do catálogo de permissões, o cache por tenant e a
+ * filtragem e proteção das chaves que o usuário não pode ler ou alterar.
+ */
+
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeRoleMetadataPolicyRepository é um RoleMetadataPolicyRepository em memória
+type fakeRoleMetadataPolicyRepository struct {
+	mu          sync.Mutex
+	permissions []string
+	granted     map[uuid.UUID][]string
+	loads       int
+	grantChecks int
+}
+
+func (r *fakeRoleMetadataPolicyRepository) ListPolicyPermissions(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loads++
+	return append([]string(nil), r.permissions...), nil
+}
+
+func (r *fakeRoleMetadataPolicyRepository) ListGrantedPermissions(ctx context.Context, tenantID, userID uuid.UUID, permissionCodes []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.grantChecks++
+	requested := make(map[string]bool, len(permissionCodes))
+	for _, code := range permissionCodes {
+		requested[code] = true
+	}
+	var granted []string
+	for _, code := range r.granted[userID] {
+		if requested[code] {
+			granted = append(granted, code)
+		}
+	}
+	return granted, nil
+}
+
+func newRoleMetadataPolicyRepository() *fakeRoleMetadataPolicyRepository {
+	return &fakeRoleMetadataPolicyRepository{
+		permissions: []string{
+			"roles:metadata.regulatory_notes:read",
+			"roles:metadata.regulatory_notes:write",
+			"roles:metadata.cost_center:write",
+			"roles:read",
+		},
+		granted: make(map[uuid.UUID][]string),
+	}
+}
+
+func TestRoleMetadataPolicyService_ListPolicies(t *testing.T) {
+	repo := newRoleMetadataPolicyRepository()
+	service := impl.NewRoleMetadataPolicyService(repo, time.Minute)
+	tenantID := uuid.New()
+
+	policies, err := service.ListPolicies(context.Background(), tenantID)
+	require.NoError(t, err)
+	require.Len(t, policies, 2, "as permissões fora do formato dos metadados devem ser ignoradas")
+
+	assert.Equal(t, "cost_center", policies[0].Key)
+	assert.Empty(t, policies[0].ReadPermission)
+	assert.Equal(t, "roles:metadata.cost_center:write", policies[0].WritePermission)
+	assert.Equal(t, "regulatory_notes", policies[1].Key)
+	assert.Equal(t, "roles:metadata.regulatory_notes:read", policies[1].ReadPermission)
+
+	policies[0].Key = "alterada"
+	_, err = service.ListPolicies(context.Background(), tenantID)
+	require.NoError(t, err)
+	policies, err = service.ListPolicies(context.Background(), tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "cost_center", policies[0].Key, "o cache não deve ser alterado pelas cópias retornadas")
+	assert.Equal(t, 1, repo.loads, "as políticas devem ficar em cache")
+
+	_, err = service.ListPolicies(context.Background(), uuid.Nil)
+	assert.ErrorIs(t, err, model.ErrInvalidTenantID)
+}
+
+func TestRoleMetadataPolicyService_CacheExpiry(t *testing.T) {
+	repo := newRoleMetadataPolicyRepository()
+	service := impl.NewRoleMetadataPolicyService(repo, time.Millisecond)
+	tenantID := uuid.New()
+
+	_, err := service.ListPolicies(context.Background(), tenantID)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = service.ListPolicies(context.Background(), tenantID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, repo.loads, "as políticas devem ser recarregadas quando o cache expira")
+}
+
+func TestRoleMetadataPolicyService_AccessWithoutPolicies(t *testing.T) {
+	repo := newRoleMetadataPolicyRepository()
+	repo.permissions = []string{"roles:read"}
+	service := impl.NewRoleMetadataPolicyService(repo, time.Minute)
+
+	access, err := service.Access(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	assert.True(t, access.CanRead("regulatory_notes"))
+	assert.True(t, access.CanWrite("regulatory_notes"))
+	assert.Equal(t, 0, repo.grantChecks, "sem políticas não devem ser consultadas as permissões do usuário")
+}
+
+func TestRoleMetadataPolicyService_FilterReadable(t *testing.T) {
+	repo := newRoleMetadataPolicyRepository()
+	service := impl.NewRoleMetadataPolicyService(repo, time.Minute)
+	tenantID, auditorID, operatorID := uuid.New(), uuid.New(), uuid.New()
+	repo.granted[auditorID] = []string{"roles:metadata.regulatory_notes:read"}
+
+	metadata := map[string]interface{}{
+		"regulatory_notes": "Processo BNA 2025/17",
+		"cost_center":      "CC-100",
+		"owner":            "compliance",
+	}
+
+	auditor, err := service.Access(context.Background(), tenantID, auditorID)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, auditor.FilterReadable(metadata))
+
+	operator, err := service.Access(context.Background(), tenantID, operatorID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cost_center": "CC-100",
+		"owner":       "compliance",
+	}, operator.FilterReadable(metadata), "a chave protegida deve ser omitida para quem não a pode ler")
+	assert.Len(t, metadata, 3, "os metadados originais não devem ser alterados")
+}
+
+func TestRoleMetadataPolicyService_ApplyWrite(t *testing.T) {
+	repo := newRoleMetadataPolicyRepository()
+	service := impl.NewRoleMetadataPolicyService(repo, time.Minute)
+	tenantID, auditorID, operatorID := uuid.New(), uuid.New(), uuid.New()
+	repo.granted[auditorID] = []string{
+		"roles:metadata.regulatory_notes:read",
+		"roles:metadata.regulatory_notes:write",
+	}
+
+	current := map[string]interface{}{
+		"regulatory_notes": "Processo BNA 2025/17",
+		"cost_center":      "CC-100",
+	}
+
+	operator, err := service.Access(context.Background(), tenantID, operatorID)
+	require.NoError(t, err)
+
+	merged, denied := operator.ApplyWrite(current, map[string]interface{}{"owner": "compliance"})
+	assert.Empty(t, denied)
+	assert.Equal(t, map[string]interface{}{
+		"regulatory_notes": "Processo BNA 2025/17",
+		"cost_center":      "CC-100",
+		"owner":            "compliance",
+	}, merged, "as chaves protegidas omitidas devem manter o valor atual")
+
+	_, denied = operator.ApplyWrite(current, map[string]interface{}{
+		"regulatory_notes": "",
+		"cost_center":      "CC-200",
+	})
+	assert.Equal(t, []string{"cost_center", "regulatory_notes"}, denied)
+
+	_, denied = operator.ApplyWrite(current, map[string]interface{}{"cost_center": "CC-100"})
+	assert.Empty(t, denied, "manter o valor atual de uma chave protegida não é uma alteração")
+
+	_, denied = operator.ApplyWrite(nil, map[string]interface{}{"regulatory_notes": "nova"})
+	assert.Equal(t, []string{"regulatory_notes"}, denied, "a criação também deve respeitar as políticas")
+
+	auditor, err := service.Access(context.Background(), tenantID, auditorID)
+	require.NoError(t, err)
+
+	merged, denied = auditor.ApplyWrite(current, map[string]interface{}{"regulatory_notes": "Processo encerrado"})
+	assert.Empty(t, denied)
+	assert.Equal(t, map[string]interface{}{
+		"regulatory_notes": "Processo encerrado",
+		"cost_center":      "CC-100",
+	}, merged)
+}
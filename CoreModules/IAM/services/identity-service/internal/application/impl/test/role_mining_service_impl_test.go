@@ -0,0 +1,357 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de mineração de funções (RoleMiningService).
+ * Valida o agrupamento das permissões diretas em sugestões de função, a aceitação que
+ * cria a função e o descarte que impede que o mesmo conjunto volte a ser sugerido.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeRoleMiningRepository é um RoleMiningRepository em memória
+type fakeRoleMiningRepository struct {
+	mu          sync.Mutex
+	assignments map[uuid.UUID][]*model.DirectPermissionAssignment
+	suggestions map[uuid.UUID]*model.RoleSuggestion
+	failTenant  uuid.UUID
+}
+
+func newFakeRoleMiningRepository() *fakeRoleMiningRepository {
+	return &fakeRoleMiningRepository{
+		assignments: make(map[uuid.UUID][]*model.DirectPermissionAssignment),
+		suggestions: make(map[uuid.UUID]*model.RoleSuggestion),
+	}
+}
+
+// grant atribui diretamente as permissões ao usuário
+func (r *fakeRoleMiningRepository) grant(tenantID, userID uuid.UUID, permissions ...uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, permissionID := range permissions {
+		r.assignments[tenantID] = append(r.assignments[tenantID], &model.DirectPermissionAssignment{
+			UserID: userID, PermissionID: permissionID, PermissionCode: "perm:" + permissionID.String()[:8],
+		})
+	}
+}
+
+func (r *fakeRoleMiningRepository) ListTenantsWithDirectPermissions(ctx context.Context) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tenants []uuid.UUID
+	for tenantID := range r.assignments {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].String() < tenants[j].String() })
+	return tenants, nil
+}
+
+func (r *fakeRoleMiningRepository) ListDirectAssignments(ctx context.Context, tenantID uuid.UUID) ([]*model.DirectPermissionAssignment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tenantID == r.failTenant {
+		return nil, errors.New("falha simulada na base de dados")
+	}
+	return append([]*model.DirectPermissionAssignment(nil), r.assignments[tenantID]...), nil
+}
+
+func (r *fakeRoleMiningRepository) ReplacePendingSuggestions(ctx context.Context, tenantID uuid.UUID, suggestions []*model.RoleSuggestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, suggestion := range r.suggestions {
+		if suggestion.TenantID == tenantID && suggestion.Status == model.RoleSuggestionStatusPending {
+			delete(r.suggestions, id)
+		}
+	}
+	for _, suggestion := range suggestions {
+		copied := *suggestion
+		r.suggestions[suggestion.ID] = &copied
+	}
+	return nil
+}
+
+func (r *fakeRoleMiningRepository) ListSuggestions(ctx context.Context, tenantID uuid.UUID, status string) ([]*model.RoleSuggestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.RoleSuggestion
+	for _, suggestion := range r.suggestions {
+		if suggestion.TenantID == tenantID && (status == "" || suggestion.Status == status) {
+			copied := *suggestion
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CoveredAssignments > result[j].CoveredAssignments })
+	return result, nil
+}
+
+func (r *fakeRoleMiningRepository) GetSuggestion(ctx context.Context, tenantID, suggestionID uuid.UUID) (*model.RoleSuggestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suggestion, ok := r.suggestions[suggestionID]
+	if !ok || suggestion.TenantID != tenantID {
+		return nil, model.ErrRoleSuggestionNotFound
+	}
+	copied := *suggestion
+	return &copied, nil
+}
+
+func (r *fakeRoleMiningRepository) SaveReview(ctx context.Context, suggestion *model.RoleSuggestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.suggestions[suggestion.ID]
+	if !ok || stored.Status != model.RoleSuggestionStatusPending {
+		return model.ErrRoleSuggestionNotPending
+	}
+	copied := *suggestion
+	r.suggestions[suggestion.ID] = &copied
+	return nil
+}
+
+// fakeMiningRoleService é um RoleService em memória com as operações usadas na aceitação de sugestões
+type fakeMiningRoleService struct {
+	application.RoleService
+	mu          sync.Mutex
+	roles       map[uuid.UUID]*model.Role
+	permissions map[uuid.UUID][]uuid.UUID
+	users       map[uuid.UUID][]uuid.UUID
+}
+
+func (f *fakeMiningRoleService) CreateRole(ctx context.Context, req application.CreateRoleRequest) (*model.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, role := range f.roles {
+		if role.TenantID == req.TenantID && role.Code == req.Code {
+			return nil, application.ErrRoleCodeAlreadyExists
+		}
+	}
+	role := &model.Role{
+		ID:       uuid.New(),
+		TenantID: req.TenantID,
+		Code:     req.Code,
+		Name:     req.Name,
+		Type:     model.RoleType(req.Type),
+		IsActive: true,
+		Metadata: req.Metadata,
+	}
+	f.roles[role.ID] = role
+	return role, nil
+}
+
+func (f *fakeMiningRoleService) AssignPermission(ctx context.Context, tenantID, roleID, permissionID, assignedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.permissions[roleID] = append(f.permissions[roleID], permissionID)
+	return nil
+}
+
+func (f *fakeMiningRoleService) AssignUserToRole(ctx context.Context, tenantID, roleID, userID uuid.UUID, activatesAt time.Time, expiresAt *time.Time, assignedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.users[roleID] = append(f.users[roleID], userID)
+	return nil
+}
+
+// roleMiningFixture contém um tenant em que seis usuários partilham as permissões de leitura
+// do livro razão, um deles com uma permissão extra, e dois usuários com permissões de tesouraria
+type roleMiningFixture struct {
+	repo        *fakeRoleMiningRepository
+	roles       *fakeMiningRoleService
+	service     application.RoleMiningService
+	tenantID    uuid.UUID
+	admin       uuid.UUID
+	ledger      []uuid.UUID
+	ledgerUsers []uuid.UUID
+}
+
+func newRoleMiningFixture(t *testing.T) *roleMiningFixture {
+	f := &roleMiningFixture{
+		repo:     newFakeRoleMiningRepository(),
+		tenantID: uuid.New(),
+		admin:    uuid.New(),
+		ledger:   []uuid.UUID{uuid.New(), uuid.New(), uuid.New()},
+	}
+	f.roles = &fakeMiningRoleService{
+		roles:       make(map[uuid.UUID]*model.Role),
+		permissions: make(map[uuid.UUID][]uuid.UUID),
+		users:       make(map[uuid.UUID][]uuid.UUID),
+	}
+	f.service = impl.NewRoleMiningService(f.repo, f.roles, impl.RoleMiningConfig{
+		MinUsers:       3,
+		MinPermissions: 2,
+		MinSimilarity:  0.6,
+	})
+
+	for i := 0; i < 5; i++ {
+		userID := uuid.New()
+		f.ledgerUsers = append(f.ledgerUsers, userID)
+		f.repo.grant(f.tenantID, userID, f.ledger...)
+	}
+	extended := uuid.New()
+	f.ledgerUsers = append(f.ledgerUsers, extended)
+	f.repo.grant(f.tenantID, extended, append([]uuid.UUID{uuid.New()}, f.ledger...)...)
+
+	treasury := []uuid.UUID{uuid.New(), uuid.New()}
+	f.repo.grant(f.tenantID, uuid.New(), treasury...)
+	f.repo.grant(f.tenantID, uuid.New(), treasury...)
+	return f
+}
+
+func (f *roleMiningFixture) mine(t *testing.T) *model.RoleSuggestion {
+	suggestions, err := f.service.MineTenant(context.Background(), f.tenantID)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1, "as permissões de tesouraria têm menos usuários que o mínimo")
+	return suggestions[0]
+}
+
+func TestRoleMiningService_MineTenant(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleMiningFixture(t)
+
+	suggestion := f.mine(t)
+	assert.Equal(t, model.RoleSuggestionStatusPending, suggestion.Status)
+	assert.ElementsMatch(t, f.ledger, suggestion.PermissionIDs)
+	assert.ElementsMatch(t, f.ledgerUsers, suggestion.UserIDs, "inclui o usuário com uma permissão extra")
+	assert.Equal(t, 6, suggestion.UserCount)
+	assert.Equal(t, 18, suggestion.CoveredAssignments)
+	assert.InDelta(t, (5+0.75)/6, suggestion.Cohesion, 0.0001)
+	assert.Len(t, suggestion.PermissionCodes, 3)
+
+	// Recalcular substitui a sugestão pendente em vez de a duplicar
+	again := f.mine(t)
+	assert.Equal(t, suggestion.Fingerprint, again.Fingerprint)
+	pending, err := f.service.ListSuggestions(ctx, f.tenantID, model.RoleSuggestionStatusPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, again.ID, pending[0].ID)
+
+	_, err = f.service.ListSuggestions(ctx, f.tenantID, "archived")
+	assert.ErrorIs(t, err, application.ErrInvalidRoleSuggestion)
+}
+
+func TestRoleMiningService_AcceptSuggestion(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleMiningFixture(t)
+	suggestion := f.mine(t)
+
+	_, err := f.service.AcceptSuggestion(ctx, &application.AcceptRoleSuggestionRequest{
+		TenantID: f.tenantID, SuggestionID: suggestion.ID, RoleName: "Leitor do Livro Razão", AcceptedBy: f.admin,
+	})
+	assert.ErrorIs(t, err, application.ErrInvalidRoleSuggestion, "o código da função é obrigatório")
+
+	acceptance, err := f.service.AcceptSuggestion(ctx, &application.AcceptRoleSuggestionRequest{
+		TenantID:     f.tenantID,
+		SuggestionID: suggestion.ID,
+		RoleCode:     "LEDGER_READER",
+		RoleName:     "Leitor do Livro Razão",
+		AssignUsers:  true,
+		AcceptedBy:   f.admin,
+	})
+	require.NoError(t, err)
+
+	role := acceptance.Role
+	assert.Equal(t, "LEDGER_READER", role.Code)
+	assert.Equal(t, model.RoleTypeCustom, role.Type)
+	assert.Equal(t, suggestion.ID.String(), role.Metadata[model.RoleMetadataSuggestionID])
+	assert.ElementsMatch(t, f.ledger, f.roles.permissions[role.ID])
+	assert.ElementsMatch(t, f.ledgerUsers, f.roles.users[role.ID])
+	assert.Equal(t, 6, acceptance.AssignedUsers)
+
+	assert.Equal(t, model.RoleSuggestionStatusAccepted, acceptance.Suggestion.Status)
+	require.NotNil(t, acceptance.Suggestion.RoleID)
+	assert.Equal(t, role.ID, *acceptance.Suggestion.RoleID)
+	require.NotNil(t, acceptance.Suggestion.ReviewedBy)
+	assert.Equal(t, f.admin, *acceptance.Suggestion.ReviewedBy)
+
+	_, err = f.service.AcceptSuggestion(ctx, &application.AcceptRoleSuggestionRequest{
+		TenantID: f.tenantID, SuggestionID: suggestion.ID, RoleCode: "LEDGER_READER_2", RoleName: "Outra", AcceptedBy: f.admin,
+	})
+	assert.ErrorIs(t, err, application.ErrRoleSuggestionNotPending)
+
+	// O conjunto aceite não volta a ser sugerido enquanto as permissões diretas se mantêm
+	suggestions, err := f.service.MineTenant(ctx, f.tenantID)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	accepted, err := f.service.ListSuggestions(ctx, f.tenantID, model.RoleSuggestionStatusAccepted)
+	require.NoError(t, err)
+	assert.Len(t, accepted, 1)
+}
+
+func TestRoleMiningService_AcceptWithoutAssigningUsers(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleMiningFixture(t)
+	suggestion := f.mine(t)
+
+	acceptance, err := f.service.AcceptSuggestion(ctx, &application.AcceptRoleSuggestionRequest{
+		TenantID: f.tenantID, SuggestionID: suggestion.ID, RoleCode: "LEDGER_READER", RoleName: "Leitor", AcceptedBy: f.admin,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, acceptance.AssignedUsers)
+	assert.Empty(t, f.roles.users[acceptance.Role.ID])
+	assert.Len(t, f.roles.permissions[acceptance.Role.ID], 3)
+}
+
+func TestRoleMiningService_DismissSuggestion(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleMiningFixture(t)
+	suggestion := f.mine(t)
+
+	_, err := f.service.DismissSuggestion(ctx, f.tenantID, uuid.New(), f.admin)
+	assert.ErrorIs(t, err, application.ErrRoleSuggestionNotFound)
+	_, err = f.service.GetSuggestion(ctx, uuid.New(), suggestion.ID)
+	assert.ErrorIs(t, err, application.ErrRoleSuggestionNotFound, "a sugestão pertence a outro tenant")
+
+	dismissed, err := f.service.DismissSuggestion(ctx, f.tenantID, suggestion.ID, f.admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleSuggestionStatusDismissed, dismissed.Status)
+	assert.Nil(t, dismissed.RoleID)
+
+	_, err = f.service.DismissSuggestion(ctx, f.tenantID, suggestion.ID, f.admin)
+	assert.ErrorIs(t, err, application.ErrRoleSuggestionNotPending)
+
+	suggestions, err := f.service.MineTenant(ctx, f.tenantID)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions, "o conjunto descartado não volta a ser sugerido")
+	assert.Empty(t, f.roles.roles)
+}
+
+func TestRoleMiningService_MineAll(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleMiningFixture(t)
+
+	broken := uuid.New()
+	f.repo.grant(broken, uuid.New(), uuid.New(), uuid.New())
+	f.repo.failTenant = broken
+
+	result, err := f.service.MineAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Tenants)
+	assert.Equal(t, 1, result.Suggestions)
+	assert.Equal(t, 1, result.Failed, "a falha de um tenant não interrompe os restantes")
+}
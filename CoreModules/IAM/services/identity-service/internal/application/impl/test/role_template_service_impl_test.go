@@ -0,0 +1,444 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço da biblioteca de modelos de função (RoleTemplateService).
+ * Valida a publicação e revisão dos modelos, a instanciação com mapeamento de permissões
+ * e a deteção de desvios entre as funções instanciadas e os modelos.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeRoleTemplateRepository é um RoleTemplateRepository em memória
+type fakeRoleTemplateRepository struct {
+	mu          sync.Mutex
+	templates   map[uuid.UUID]*model.RoleTemplate
+	instances   []*model.RoleTemplateInstance
+	permissions map[uuid.UUID]map[string]uuid.UUID
+}
+
+func newFakeRoleTemplateRepository() *fakeRoleTemplateRepository {
+	return &fakeRoleTemplateRepository{
+		templates:   make(map[uuid.UUID]*model.RoleTemplate),
+		permissions: make(map[uuid.UUID]map[string]uuid.UUID),
+	}
+}
+
+func (r *fakeRoleTemplateRepository) CreateTemplate(ctx context.Context, template *model.RoleTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.templates {
+		if existing.Code == template.Code && existing.Market == template.Market {
+			return model.ErrRoleTemplateCodeAlreadyExists
+		}
+	}
+	copied := *template
+	r.templates[template.ID] = &copied
+	return nil
+}
+
+func (r *fakeRoleTemplateRepository) GetTemplate(ctx context.Context, templateID uuid.UUID) (*model.RoleTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok {
+		return nil, model.ErrRoleTemplateNotFound
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (r *fakeRoleTemplateRepository) ListTemplates(ctx context.Context, filter model.RoleTemplateFilter) ([]*model.RoleTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.RoleTemplate
+	for _, template := range r.templates {
+		if filter.ActiveOnly && !template.IsActive {
+			continue
+		}
+		if filter.Market != "" && !template.AvailableIn(filter.Market) {
+			continue
+		}
+		copied := *template
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code+result[i].Market < result[j].Code+result[j].Market })
+	return result, nil
+}
+
+func (r *fakeRoleTemplateRepository) UpdateTemplate(ctx context.Context, template *model.RoleTemplate, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.templates[template.ID]
+	if !ok || stored.Version != expectedVersion {
+		return model.ErrRoleTemplateConflict
+	}
+	copied := *template
+	r.templates[template.ID] = &copied
+	return nil
+}
+
+func (r *fakeRoleTemplateRepository) ResolvePermissions(ctx context.Context, tenantID uuid.UUID, codes []string) (map[string]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resolved := make(map[string]uuid.UUID)
+	for _, code := range codes {
+		if id, ok := r.permissions[tenantID][code]; ok {
+			resolved[code] = id
+		}
+	}
+	return resolved, nil
+}
+
+func (r *fakeRoleTemplateRepository) CreateInstance(ctx context.Context, instance *model.RoleTemplateInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.instances {
+		if existing.TenantID == instance.TenantID && existing.TemplateID == instance.TemplateID {
+			return model.ErrRoleTemplateAlreadyInstantiated
+		}
+	}
+	copied := *instance
+	r.instances = append(r.instances, &copied)
+	return nil
+}
+
+func (r *fakeRoleTemplateRepository) GetInstanceByRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.RoleTemplateInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, instance := range r.instances {
+		if instance.TenantID == tenantID && instance.RoleID == roleID {
+			copied := *instance
+			return &copied, nil
+		}
+	}
+	return nil, model.ErrRoleTemplateInstanceNotFound
+}
+
+func (r *fakeRoleTemplateRepository) ListInstances(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.RoleTemplateInstance
+	for _, instance := range r.instances {
+		if instance.TenantID == tenantID {
+			copied := *instance
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+// addPermission cria uma permissão no tenant
+func (r *fakeRoleTemplateRepository) addPermission(tenantID uuid.UUID, code string) uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.permissions[tenantID] == nil {
+		r.permissions[tenantID] = make(map[string]uuid.UUID)
+	}
+	id := uuid.New()
+	r.permissions[tenantID][code] = id
+	return id
+}
+
+// fakeTemplateRoleService é um RoleService em memória com as operações usadas pelos modelos de função
+type fakeTemplateRoleService struct {
+	application.RoleService
+	mu          sync.Mutex
+	roles       map[uuid.UUID]*model.Role
+	permissions map[uuid.UUID][]*model.Permission
+	catalog     *fakeRoleTemplateRepository
+}
+
+func (f *fakeTemplateRoleService) CreateRole(ctx context.Context, req application.CreateRoleRequest) (*model.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, role := range f.roles {
+		if role.TenantID == req.TenantID && role.Code == req.Code {
+			return nil, application.ErrRoleCodeAlreadyExists
+		}
+	}
+	role := &model.Role{
+		ID:          uuid.New(),
+		TenantID:    req.TenantID,
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        model.RoleType(req.Type),
+		IsActive:    true,
+		Metadata:    req.Metadata,
+	}
+	f.roles[role.ID] = role
+	return role, nil
+}
+
+func (f *fakeTemplateRoleService) GetRolePermissions(ctx context.Context, tenantID, roleID uuid.UUID, pagination application.Pagination) ([]*model.Permission, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	all := f.permissions[roleID]
+	start := (pagination.Page - 1) * pagination.PageSize
+	if start >= len(all) {
+		return nil, int64(len(all)), nil
+	}
+	end := start + pagination.PageSize
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[start:end], int64(len(all)), nil
+}
+
+func (f *fakeTemplateRoleService) AssignPermission(ctx context.Context, tenantID, roleID, permissionID, assignedBy uuid.UUID) error {
+	f.catalog.mu.Lock()
+	var code string
+	for c, id := range f.catalog.permissions[tenantID] {
+		if id == permissionID {
+			code = c
+		}
+	}
+	f.catalog.mu.Unlock()
+	if code == "" {
+		return application.ErrPermissionNotFound
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.permissions[roleID] = append(f.permissions[roleID], &model.Permission{ID: permissionID, TenantID: tenantID, Code: code})
+	return nil
+}
+
+// revoke retira uma permissão da função fora do serviço de modelos
+func (f *fakeTemplateRoleService) revoke(roleID uuid.UUID, code string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kept := f.permissions[roleID][:0]
+	for _, permission := range f.permissions[roleID] {
+		if permission.Code != code {
+			kept = append(kept, permission)
+		}
+	}
+	f.permissions[roleID] = kept
+}
+
+// roleTemplateFixture contém o catálogo e um tenant angolano com permissões próprias
+type roleTemplateFixture struct {
+	repo     *fakeRoleTemplateRepository
+	roles    *fakeTemplateRoleService
+	service  application.RoleTemplateService
+	tenantID uuid.UUID
+	operator uuid.UUID
+	admin    uuid.UUID
+}
+
+func newRoleTemplateFixture(t *testing.T) *roleTemplateFixture {
+	f := &roleTemplateFixture{
+		repo:     newFakeRoleTemplateRepository(),
+		tenantID: uuid.New(),
+		operator: uuid.New(),
+		admin:    uuid.New(),
+	}
+	f.roles = &fakeTemplateRoleService{
+		roles:       make(map[uuid.UUID]*model.Role),
+		permissions: make(map[uuid.UUID][]*model.Permission),
+		catalog:     f.repo,
+	}
+	f.service = impl.NewRoleTemplateService(f.repo, f.roles)
+
+	for _, code := range []string{"reports:read", "ledger:read", "ledger:export", "audit:read"} {
+		f.repo.addPermission(f.tenantID, code)
+	}
+	return f
+}
+
+func (f *roleTemplateFixture) publish(t *testing.T, code, market string, permissionCodes ...string) *model.RoleTemplate {
+	template, err := f.service.CreateTemplate(context.Background(), &application.CreateRoleTemplateRequest{
+		Code:            code,
+		Name:            "Analista Financeiro",
+		Market:          market,
+		PermissionCodes: permissionCodes,
+		CreatedBy:       f.operator,
+	})
+	require.NoError(t, err)
+	return template
+}
+
+func TestRoleTemplateService_CreateAndRevise(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleTemplateFixture(t)
+
+	template := f.publish(t, "FINANCIAL_ANALYST", "Angola", " reports:read", "ledger:read", "reports:read", "")
+	assert.Equal(t, 1, template.Version)
+	assert.Equal(t, "angola", template.Market)
+	assert.Equal(t, []string{"ledger:read", "reports:read"}, template.PermissionCodes, "códigos normalizados e sem duplicados")
+
+	_, err := f.service.CreateTemplate(ctx, &application.CreateRoleTemplateRequest{
+		Code: "FINANCIAL_ANALYST", Name: "Duplicado", Market: "angola", PermissionCodes: []string{"reports:read"},
+	})
+	assert.ErrorIs(t, err, application.ErrRoleTemplateCodeAlreadyExists)
+
+	_, err = f.service.CreateTemplate(ctx, &application.CreateRoleTemplateRequest{Code: "EMPTY", Name: "Sem permissões"})
+	assert.ErrorIs(t, err, application.ErrInvalidRoleTemplate)
+
+	// Alterar apenas o nome mantém a versão
+	revised, err := f.service.UpdateTemplate(ctx, &application.UpdateRoleTemplateRequest{
+		TemplateID: template.ID, Name: "Analista Financeiro Sénior",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, revised.Version)
+	assert.Equal(t, []string{"ledger:read", "reports:read"}, revised.PermissionCodes)
+
+	// Alterar as permissões avança a versão
+	revised, err = f.service.UpdateTemplate(ctx, &application.UpdateRoleTemplateRequest{
+		TemplateID: template.ID, PermissionCodes: []string{"reports:read", "ledger:read", "ledger:export"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, revised.Version)
+	assert.Equal(t, "Analista Financeiro Sénior", revised.Name)
+
+	// O catálogo filtrado por mercado inclui os modelos sem mercado
+	f.publish(t, "COMPLIANCE_OFFICER", "", "audit:read")
+	f.publish(t, "FINANCIAL_ANALYST", "brazil", "reports:read")
+	templates, err := f.service.ListTemplates(ctx, model.RoleTemplateFilter{Market: "angola", ActiveOnly: true})
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "COMPLIANCE_OFFICER", templates[0].Code)
+	assert.Equal(t, "angola", templates[1].Market)
+}
+
+func TestRoleTemplateService_Instantiate(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleTemplateFixture(t)
+	template := f.publish(t, "FINANCIAL_ANALYST", "angola", "reports:read", "finance:ledger:read", "treasury:read")
+
+	instantiate := func(req application.InstantiateRoleTemplateRequest) (*application.RoleTemplateInstantiation, error) {
+		req.TemplateID = template.ID
+		req.TenantID = f.tenantID
+		req.InstantiatedBy = f.admin
+		return f.service.Instantiate(ctx, &req)
+	}
+
+	// Mercado diferente do modelo
+	_, err := instantiate(application.InstantiateRoleTemplateRequest{Market: "brazil"})
+	assert.ErrorIs(t, err, application.ErrRoleTemplateNotAvailable)
+
+	// Sem correspondência para treasury:read nem para finance:ledger:read
+	_, err = instantiate(application.InstantiateRoleTemplateRequest{Market: "angola"})
+	assert.ErrorIs(t, err, application.ErrRoleTemplatePermissionsUnmapped)
+	assert.Empty(t, f.roles.roles, "nenhuma função criada quando faltam correspondências")
+
+	result, err := instantiate(application.InstantiateRoleTemplateRequest{
+		Market:            "angola",
+		RoleCode:          "ANALISTA_FINANCEIRO",
+		PermissionMapping: map[string]string{"finance:ledger:read": "ledger:read"},
+		AllowPartial:      true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ANALISTA_FINANCEIRO", result.Role.Code)
+	assert.Equal(t, template.Name, result.Role.Name)
+	assert.Equal(t, model.RoleTypeCustom, result.Role.Type)
+	assert.Equal(t, template.ID.String(), result.Role.Metadata[model.RoleMetadataTemplateID])
+	assert.Equal(t, 1, result.Role.Metadata[model.RoleMetadataTemplateVersion])
+
+	assert.Equal(t, map[string]string{"finance:ledger:read": "ledger:read"}, result.Instance.PermissionMapping)
+	assert.Equal(t, []string{"treasury:read"}, result.Instance.UnmappedCodes)
+
+	codes := make([]string, 0)
+	for _, permission := range f.roles.permissions[result.Role.ID] {
+		codes = append(codes, permission.Code)
+	}
+	assert.ElementsMatch(t, []string{"reports:read", "ledger:read"}, codes)
+
+	// Uma segunda instância do mesmo modelo no tenant é rejeitada
+	_, err = instantiate(application.InstantiateRoleTemplateRequest{RoleCode: "OUTRA", AllowPartial: true,
+		PermissionMapping: map[string]string{"finance:ledger:read": "ledger:read"}})
+	assert.ErrorIs(t, err, application.ErrRoleTemplateAlreadyInstantiated)
+
+	// Modelos inativos não podem ser instanciados
+	inactive := false
+	_, err = f.service.UpdateTemplate(ctx, &application.UpdateRoleTemplateRequest{TemplateID: template.ID, IsActive: &inactive})
+	require.NoError(t, err)
+	_, err = instantiate(application.InstantiateRoleTemplateRequest{AllowPartial: true})
+	assert.ErrorIs(t, err, application.ErrRoleTemplateInactive)
+}
+
+func TestRoleTemplateService_DetectDrift(t *testing.T) {
+	ctx := context.Background()
+	f := newRoleTemplateFixture(t)
+	template := f.publish(t, "FINANCIAL_ANALYST", "", "reports:read", "finance:ledger:read", "treasury:read")
+
+	result, err := f.service.Instantiate(ctx, &application.InstantiateRoleTemplateRequest{
+		TemplateID:        template.ID,
+		TenantID:          f.tenantID,
+		PermissionMapping: map[string]string{"finance:ledger:read": "ledger:read"},
+		AllowPartial:      true,
+		InstantiatedBy:    f.admin,
+	})
+	require.NoError(t, err)
+	roleID := result.Role.ID
+
+	// Logo após a instanciação não há desvio; o código sem correspondência é apenas reportado
+	drift, err := f.service.DetectDrift(ctx, f.tenantID, roleID)
+	require.NoError(t, err)
+	assert.False(t, drift.HasDrift)
+	assert.Empty(t, drift.MissingPermissions)
+	assert.Empty(t, drift.ExtraPermissions)
+	assert.Equal(t, []string{"treasury:read"}, drift.UnmappedPermissions)
+
+	// Alterações feitas diretamente na função
+	f.roles.revoke(roleID, "ledger:read")
+	auditID := f.repo.permissions[f.tenantID]["audit:read"]
+	require.NoError(t, f.roles.AssignPermission(ctx, f.tenantID, roleID, auditID, f.admin))
+
+	drift, err = f.service.DetectDrift(ctx, f.tenantID, roleID)
+	require.NoError(t, err)
+	assert.True(t, drift.HasDrift)
+	assert.False(t, drift.Outdated)
+	assert.Equal(t, []string{"ledger:read"}, drift.MissingPermissions, "reportado com o código do tenant")
+	assert.Equal(t, []string{"audit:read"}, drift.ExtraPermissions)
+
+	// Revisão do modelo depois da instanciação
+	_, err = f.service.UpdateTemplate(ctx, &application.UpdateRoleTemplateRequest{
+		TemplateID:      template.ID,
+		PermissionCodes: []string{"reports:read", "finance:ledger:read", "treasury:read", "ledger:export"},
+	})
+	require.NoError(t, err)
+
+	drifts, err := f.service.DetectTenantDrift(ctx, f.tenantID)
+	require.NoError(t, err)
+	require.Len(t, drifts, 1)
+	assert.True(t, drifts[0].Outdated)
+	assert.Equal(t, 2, drifts[0].TemplateVersion)
+	assert.Equal(t, 1, drifts[0].InstanceVersion)
+	assert.Equal(t, []string{"ledger:export", "ledger:read"}, drifts[0].MissingPermissions)
+
+	// Funções que não provêm de modelos
+	_, err = f.service.DetectDrift(ctx, f.tenantID, uuid.New())
+	assert.True(t, errors.Is(err, application.ErrRoleTemplateInstanceNotFound))
+}
@@ -0,0 +1,657 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de federação SAML 2.0 (SAMLFederationService).
+ * Valida o registo dos fornecedores de identidade, a proteção contra respostas
+ * reapresentadas, o provisionamento no primeiro login e a sincronização das funções mapeadas.
+ */
+
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeSAMLFederationRepository é um SAMLFederationRepository em memória
+type fakeSAMLFederationRepository struct {
+	mu         sync.Mutex
+	providers  map[uuid.UUID]*model.SAMLIdentityProvider
+	requests   map[string]*model.SAMLAuthnRequest
+	identities map[string]*model.FederatedIdentity
+	users      map[uuid.UUID]*model.User
+}
+
+func newFakeSAMLFederationRepository() *fakeSAMLFederationRepository {
+	return &fakeSAMLFederationRepository{
+		providers:  make(map[uuid.UUID]*model.SAMLIdentityProvider),
+		requests:   make(map[string]*model.SAMLAuthnRequest),
+		identities: make(map[string]*model.FederatedIdentity),
+		users:      make(map[uuid.UUID]*model.User),
+	}
+}
+
+func (r *fakeSAMLFederationRepository) CreateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.providers {
+		if existing.TenantID == provider.TenantID && existing.EntityID == provider.EntityID {
+			return model.ErrSAMLProviderAlreadyExists
+		}
+	}
+	copied := *provider
+	r.providers[provider.ID] = &copied
+	return nil
+}
+
+func (r *fakeSAMLFederationRepository) GetProvider(ctx context.Context, providerID uuid.UUID) (*model.SAMLIdentityProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	provider, ok := r.providers[providerID]
+	if !ok {
+		return nil, model.ErrSAMLProviderNotFound
+	}
+	copied := *provider
+	return &copied, nil
+}
+
+func (r *fakeSAMLFederationRepository) ListProviders(ctx context.Context, tenantID uuid.UUID) ([]*model.SAMLIdentityProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*model.SAMLIdentityProvider
+	for _, provider := range r.providers {
+		if provider.TenantID == tenantID {
+			copied := *provider
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeSAMLFederationRepository) UpdateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[provider.ID]; !ok {
+		return model.ErrSAMLProviderNotFound
+	}
+	copied := *provider
+	r.providers[provider.ID] = &copied
+	return nil
+}
+
+func (r *fakeSAMLFederationRepository) SaveAuthnRequest(ctx context.Context, request *model.SAMLAuthnRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *request
+	r.requests[request.RelayState] = &copied
+	return nil
+}
+
+func (r *fakeSAMLFederationRepository) ConsumeAuthnRequest(ctx context.Context, providerID uuid.UUID, relayState string, now time.Time) (*model.SAMLAuthnRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, ok := r.requests[relayState]
+	if !ok || request.ProviderID != providerID || request.ConsumedAt != nil || !request.ExpiresAt.After(now) {
+		return nil, model.ErrSAMLRequestNotFound
+	}
+	request.ConsumedAt = &now
+	copied := *request
+	return &copied, nil
+}
+
+func (r *fakeSAMLFederationRepository) GetFederatedIdentity(ctx context.Context, providerID uuid.UUID, subject string) (*model.FederatedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[providerID.String()+"|"+subject]
+	if !ok {
+		return nil, model.ErrFederatedIdentityNotFound
+	}
+	copied := *identity
+	copied.GrantedRoleIDs = append([]uuid.UUID{}, identity.GrantedRoleIDs...)
+	return &copied, nil
+}
+
+func (r *fakeSAMLFederationRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, model.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeSAMLFederationRepository) ProvisionUser(ctx context.Context, user *model.User, identity *model.FederatedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.TenantID == user.TenantID && (existing.Email == user.Email || existing.Username == user.Username) {
+			return model.ErrSAMLAccountConflict
+		}
+	}
+	copiedUser := *user
+	r.users[user.ID] = &copiedUser
+	copiedIdentity := *identity
+	r.identities[identity.ProviderID.String()+"|"+identity.Subject] = &copiedIdentity
+	return nil
+}
+
+func (r *fakeSAMLFederationRepository) RecordLogin(ctx context.Context, identity *model.FederatedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *identity
+	copied.GrantedRoleIDs = append([]uuid.UUID{}, identity.GrantedRoleIDs...)
+	r.identities[identity.ProviderID.String()+"|"+identity.Subject] = &copied
+	if user, ok := r.users[identity.UserID]; ok {
+		user.LoginCount++
+		user.LastLoginAt = identity.LastLoginAt
+	}
+	return nil
+}
+
+// addLocalUser cria uma conta local fora da federação
+func (r *fakeSAMLFederationRepository) addLocalUser(tenantID uuid.UUID, email string) *model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := &model.User{ID: uuid.New(), TenantID: tenantID, Username: email, Email: email, Status: model.UserStatusActive}
+	r.users[user.ID] = user
+	return user
+}
+
+// fakeSAMLServiceProvider simula o SP: a asserção devolvida é a configurada no teste
+type fakeSAMLServiceProvider struct {
+	assertion *model.SAMLAssertion
+	parsedFor []string
+	metadata  *model.SAMLIdentityProvider
+}
+
+func (p *fakeSAMLServiceProvider) Metadata(provider *model.SAMLIdentityProvider) ([]byte, error) {
+	return []byte("<EntityDescriptor entityID=\"sp-" + provider.ID.String() + "\"/>"), nil
+}
+
+func (p *fakeSAMLServiceProvider) AuthnRequest(provider *model.SAMLIdentityProvider, relayState string) (string, string, error) {
+	requestID := "id-" + uuid.New().String()
+	return requestID, provider.SSOURL + "?SAMLRequest=" + requestID + "&RelayState=" + relayState, nil
+}
+
+func (p *fakeSAMLServiceProvider) ParseResponse(provider *model.SAMLIdentityProvider, samlResponse, requestID string) (*model.SAMLAssertion, error) {
+	p.parsedFor = append(p.parsedFor, requestID)
+	if samlResponse != "signed" || p.assertion == nil {
+		return nil, model.ErrInvalidSAMLResponse
+	}
+	copied := *p.assertion
+	return &copied, nil
+}
+
+func (p *fakeSAMLServiceProvider) ParseIdPMetadata(metadata []byte) (*model.SAMLIdentityProvider, error) {
+	if p.metadata == nil {
+		return nil, model.ErrInvalidSAMLMetadata
+	}
+	copied := *p.metadata
+	return &copied, nil
+}
+
+// fakeSAMLRoleService é um RoleService em memória com as operações usadas pela federação
+type fakeSAMLRoleService struct {
+	application.RoleService
+	mu          sync.Mutex
+	roles       map[uuid.UUID]*model.Role
+	assignments map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func (f *fakeSAMLRoleService) GetRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role, ok := f.roles[roleID]
+	if !ok || role.TenantID != tenantID {
+		return nil, application.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (f *fakeSAMLRoleService) AssignUserToRole(ctx context.Context, tenantID, roleID, userID uuid.UUID, activatesAt time.Time, expiresAt *time.Time, assignedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.assignments[userID] == nil {
+		f.assignments[userID] = make(map[uuid.UUID]bool)
+	}
+	if f.assignments[userID][roleID] {
+		return application.ErrUserAlreadyAssigned
+	}
+	f.assignments[userID][roleID] = true
+	return nil
+}
+
+func (f *fakeSAMLRoleService) RevokeUserFromRole(ctx context.Context, tenantID, roleID, userID, revokedBy uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.assignments[userID][roleID] {
+		return application.ErrUserNotAssigned
+	}
+	delete(f.assignments[userID], roleID)
+	return nil
+}
+
+func (f *fakeSAMLRoleService) hasRole(userID, roleID uuid.UUID) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.assignments[userID][roleID]
+}
+
+// fakeAccountLinkDetector devolve o candidato configurado no teste e guarda os pedidos recebidos
+type fakeAccountLinkDetector struct {
+	candidate *model.AccountLinkCandidate
+	requests  []*application.DetectAccountLinkRequest
+}
+
+func (d *fakeAccountLinkDetector) DetectCandidate(ctx context.Context, req *application.DetectAccountLinkRequest) (*model.AccountLinkCandidate, error) {
+	d.requests = append(d.requests, req)
+	return d.candidate, nil
+}
+
+// samlFederationFixture contém um tenant com duas funções mapeáveis a partir do atributo groups
+type samlFederationFixture struct {
+	repo        *fakeSAMLFederationRepository
+	sp          *fakeSAMLServiceProvider
+	roles       *fakeSAMLRoleService
+	legal       *fakeLegalAcceptanceGate
+	service     application.SAMLFederationService
+	tenantID    uuid.UUID
+	admin       uuid.UUID
+	analystRole uuid.UUID
+	auditorRole uuid.UUID
+	certificate string
+}
+
+func newSAMLFederationFixture(t *testing.T) *samlFederationFixture {
+	f := &samlFederationFixture{
+		repo:        newFakeSAMLFederationRepository(),
+		sp:          &fakeSAMLServiceProvider{},
+		legal:       &fakeLegalAcceptanceGate{},
+		tenantID:    uuid.New(),
+		admin:       uuid.New(),
+		analystRole: uuid.New(),
+		auditorRole: uuid.New(),
+		certificate: selfSignedCertificatePEM(t),
+	}
+	f.roles = &fakeSAMLRoleService{
+		roles: map[uuid.UUID]*model.Role{
+			f.analystRole: {ID: f.analystRole, TenantID: f.tenantID, Code: "ANALYST"},
+			f.auditorRole: {ID: f.auditorRole, TenantID: f.tenantID, Code: "AUDITOR"},
+		},
+		assignments: make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+	f.service = impl.NewSAMLFederationService(f.repo, f.sp, f.roles, nil, f.legal, time.Minute)
+	return f
+}
+
+func (f *samlFederationFixture) register(t *testing.T, jit bool) *model.SAMLIdentityProvider {
+	provider, err := f.service.RegisterProvider(context.Background(), &application.RegisterSAMLProviderRequest{
+		TenantID:            f.tenantID,
+		Name:                "Azure AD Corporativo",
+		EntityID:            "https://sts.windows.net/" + f.tenantID.String() + "/",
+		SSOURL:              "https://login.microsoftonline.com/saml2",
+		SigningCertificates: []string{f.certificate},
+		RoleMappingRules: []model.SAMLRoleMappingRule{
+			{Attribute: "groups", Operator: model.SAMLRoleMatchEquals, Value: "Finance-Analysts", RoleID: f.analystRole},
+			{Attribute: "groups", Operator: model.SAMLRoleMatchRegex, Value: "audit-.*", RoleID: f.auditorRole},
+		},
+		JITProvisioning: jit,
+		CreatedBy:       f.admin,
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+// login inicia o login e apresenta uma resposta assinada com os atributos indicados
+func (f *samlFederationFixture) login(t *testing.T, providerID uuid.UUID, nameID string, groups ...string) (*application.SAMLLoginResult, error) {
+	redirect, err := f.service.StartLogin(context.Background(), providerID, "/portal/home")
+	require.NoError(t, err)
+
+	f.sp.assertion = &model.SAMLAssertion{
+		NameID:       nameID,
+		SessionIndex: "session-1",
+		Attributes: map[string][]string{
+			"email":     {nameID},
+			"givenName": {"Ana"},
+			"sn":        {"Silva"},
+			"groups":    groups,
+		},
+	}
+	return f.service.CompleteLogin(context.Background(), providerID, "signed", redirect.RelayState)
+}
+
+// selfSignedCertificatePEM gera um certificado de assinatura do IdP para os testes
+func selfSignedCertificatePEM(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestSAMLFederationService_RegisterProvider(t *testing.T) {
+	f := newSAMLFederationFixture(t)
+	ctx := context.Background()
+
+	provider := f.register(t, true)
+	assert.True(t, provider.IsActive)
+
+	t.Run("entity ID repetido no tenant", func(t *testing.T) {
+		_, err := f.service.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+			TenantID:            f.tenantID,
+			Name:                "Duplicado",
+			EntityID:            provider.EntityID,
+			SSOURL:              provider.SSOURL,
+			SigningCertificates: []string{f.certificate},
+		})
+		assert.ErrorIs(t, err, application.ErrSAMLProviderAlreadyExists)
+	})
+
+	t.Run("função mapeada de outro tenant", func(t *testing.T) {
+		_, err := f.service.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+			TenantID:            f.tenantID,
+			Name:                "Okta",
+			EntityID:            "http://www.okta.com/exk1",
+			SSOURL:              "https://acme.okta.com/app/sso/saml",
+			SigningCertificates: []string{f.certificate},
+			RoleMappingRules:    []model.SAMLRoleMappingRule{{Attribute: "groups", Operator: model.SAMLRoleMatchPresent, RoleID: uuid.New()}},
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidSAMLProvider)
+	})
+
+	t.Run("certificado inválido", func(t *testing.T) {
+		_, err := f.service.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+			TenantID:            f.tenantID,
+			Name:                "Okta",
+			EntityID:            "http://www.okta.com/exk2",
+			SSOURL:              "https://acme.okta.com/app/sso/saml",
+			SigningCertificates: []string{"não é um certificado"},
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidSAMLProvider)
+	})
+
+	t.Run("dados lidos dos metadados do IdP", func(t *testing.T) {
+		f.sp.metadata = &model.SAMLIdentityProvider{
+			EntityID:            "https://idp.example.com/metadata",
+			SSOURL:              "https://idp.example.com/sso",
+			SigningCertificates: []string{f.certificate},
+			NameIDFormat:        model.SAMLNameIDFormatPersistent,
+		}
+		registered, err := f.service.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+			TenantID:    f.tenantID,
+			Name:        "Keycloak",
+			MetadataXML: "<EntityDescriptor/>",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.com/metadata", registered.EntityID)
+		assert.Equal(t, model.SAMLNameIDFormatPersistent, registered.NameIDFormat)
+	})
+
+	t.Run("outro tenant não vê o fornecedor", func(t *testing.T) {
+		_, err := f.service.GetProvider(ctx, uuid.New(), provider.ID)
+		assert.ErrorIs(t, err, application.ErrSAMLProviderNotFound)
+
+		found, err := f.service.GetProvider(ctx, f.tenantID, provider.ID)
+		require.NoError(t, err)
+		assert.Equal(t, provider.EntityID, found.EntityID)
+	})
+}
+
+func TestSAMLFederationService_StartLogin(t *testing.T) {
+	f := newSAMLFederationFixture(t)
+	ctx := context.Background()
+	provider := f.register(t, true)
+
+	for _, returnTo := range []string{"https://evil.example.com/", "//evil.example.com/path", "javascript:alert(1)"} {
+		_, err := f.service.StartLogin(ctx, provider.ID, returnTo)
+		assert.ErrorIs(t, err, application.ErrInvalidSAMLReturnTo, returnTo)
+	}
+
+	redirect, err := f.service.StartLogin(ctx, provider.ID, "/portal")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(redirect.RedirectURL, provider.SSOURL))
+	assert.NotEmpty(t, redirect.RelayState)
+
+	other, err := f.service.StartLogin(ctx, provider.ID, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, redirect.RelayState, other.RelayState)
+
+	inactive := false
+	_, err = f.service.UpdateProvider(ctx, &application.UpdateSAMLProviderRequest{
+		TenantID:   f.tenantID,
+		ProviderID: provider.ID,
+		IsActive:   &inactive,
+		UpdatedBy:  f.admin,
+	})
+	require.NoError(t, err)
+	_, err = f.service.StartLogin(ctx, provider.ID, "/portal")
+	assert.ErrorIs(t, err, application.ErrSAMLProviderInactive)
+}
+
+func TestSAMLFederationService_CompleteLogin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("provisiona o usuário e atribui as funções mapeadas", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+
+		result, err := f.login(t, provider.ID, "Ana.Silva@Acme.co.ao", "finance-analysts")
+		require.NoError(t, err)
+
+		assert.True(t, result.Provisioned)
+		assert.Equal(t, "/portal/home", result.ReturnTo)
+		assert.Equal(t, "ana.silva@acme.co.ao", result.User.Email)
+		assert.Equal(t, model.UserStatusActive, result.User.Status)
+		assert.True(t, result.User.EmailVerified)
+		assert.Equal(t, provider.ID.String(), result.User.Metadata[model.UserMetadataSAMLProviderID])
+		assert.Equal(t, []uuid.UUID{f.analystRole}, result.RolesGranted)
+		assert.True(t, f.roles.hasRole(result.User.ID, f.analystRole))
+		assert.NotNil(t, result.Identity.LastLoginAt)
+
+		again, err := f.login(t, provider.ID, "Ana.Silva@Acme.co.ao", "finance-analysts")
+		require.NoError(t, err)
+		assert.False(t, again.Provisioned)
+		assert.Equal(t, result.User.ID, again.User.ID)
+		assert.Empty(t, again.RolesGranted)
+	})
+
+	t.Run("resposta reapresentada é rejeitada", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+
+		redirect, err := f.service.StartLogin(ctx, provider.ID, "")
+		require.NoError(t, err)
+		f.sp.assertion = &model.SAMLAssertion{NameID: "ana@acme.co.ao"}
+
+		_, err = f.service.CompleteLogin(ctx, provider.ID, "signed", redirect.RelayState)
+		require.NoError(t, err)
+		_, err = f.service.CompleteLogin(ctx, provider.ID, "signed", redirect.RelayState)
+		assert.ErrorIs(t, err, application.ErrSAMLRequestNotFound)
+
+		_, err = f.service.CompleteLogin(ctx, provider.ID, "signed", "relay-state-desconhecido")
+		assert.ErrorIs(t, err, application.ErrSAMLRequestNotFound)
+		assert.Len(t, f.sp.parsedFor, 1, "respostas sem pedido válido não chegam a ser processadas")
+	})
+
+	t.Run("pedido consumido mesmo com resposta inválida", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+
+		redirect, err := f.service.StartLogin(ctx, provider.ID, "")
+		require.NoError(t, err)
+		f.sp.assertion = &model.SAMLAssertion{NameID: "ana@acme.co.ao"}
+
+		_, err = f.service.CompleteLogin(ctx, provider.ID, "adulterada", redirect.RelayState)
+		assert.ErrorIs(t, err, application.ErrInvalidSAMLResponse)
+		_, err = f.service.CompleteLogin(ctx, provider.ID, "signed", redirect.RelayState)
+		assert.ErrorIs(t, err, application.ErrSAMLRequestNotFound)
+	})
+
+	t.Run("relay state de outro fornecedor", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+		f.sp.metadata = &model.SAMLIdentityProvider{
+			EntityID:            "https://idp.example.com/metadata",
+			SSOURL:              "https://idp.example.com/sso",
+			SigningCertificates: []string{f.certificate},
+		}
+		other, err := f.service.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+			TenantID: f.tenantID, Name: "Outro", MetadataXML: "<EntityDescriptor/>", JITProvisioning: true,
+		})
+		require.NoError(t, err)
+
+		redirect, err := f.service.StartLogin(ctx, provider.ID, "")
+		require.NoError(t, err)
+		f.sp.assertion = &model.SAMLAssertion{NameID: "ana@acme.co.ao"}
+
+		_, err = f.service.CompleteLogin(ctx, other.ID, "signed", redirect.RelayState)
+		assert.ErrorIs(t, err, application.ErrSAMLRequestNotFound)
+	})
+
+	t.Run("provisionamento desativado", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, false)
+
+		_, err := f.login(t, provider.ID, "ana@acme.co.ao")
+		assert.ErrorIs(t, err, application.ErrSAMLProvisioningDisabled)
+	})
+
+	t.Run("conta local com o mesmo email não é ligada", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+		f.repo.addLocalUser(f.tenantID, "ana@acme.co.ao")
+
+		_, err := f.login(t, provider.ID, "ana@acme.co.ao")
+		assert.ErrorIs(t, err, application.ErrSAMLAccountConflict)
+	})
+
+	t.Run("conta local com email verificado gera candidato à ligação", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		user := f.repo.addLocalUser(f.tenantID, "ana@acme.co.ao")
+		detector := &fakeAccountLinkDetector{
+			candidate: &model.AccountLinkCandidate{ID: uuid.New(), TenantID: f.tenantID, UserID: user.ID},
+		}
+		f.service = impl.NewSAMLFederationService(f.repo, f.sp, f.roles, detector, f.legal, time.Minute)
+		provider := f.register(t, true)
+
+		result, err := f.login(t, provider.ID, "Ana@Acme.co.ao", "finance-analysts")
+		require.NoError(t, err)
+		assert.Equal(t, detector.candidate, result.LinkCandidate)
+		assert.Nil(t, result.User)
+		assert.False(t, result.Provisioned)
+		assert.False(t, f.roles.hasRole(user.ID, f.analystRole), "as funções só são atribuídas depois da ligação")
+
+		require.Len(t, detector.requests, 1)
+		assert.Equal(t, provider.ID, detector.requests[0].ProviderID)
+		assert.Equal(t, "Ana@Acme.co.ao", detector.requests[0].Subject)
+		assert.Equal(t, "ana@acme.co.ao", detector.requests[0].Email)
+
+		detector.candidate = nil
+		_, err = f.login(t, provider.ID, "Ana@Acme.co.ao")
+		assert.ErrorIs(t, err, application.ErrSAMLAccountConflict, "sem candidato segue o provisionamento")
+	})
+
+	t.Run("documentos legais por aceitar", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+		terms := &model.LegalDocument{ID: uuid.New(), TenantID: f.tenantID, Type: model.LegalDocumentTermsOfService, Required: true}
+		f.legal.pending = []*model.LegalDocument{terms}
+
+		result, err := f.login(t, provider.ID, "ana@acme.co.ao", "Finance-Analysts")
+		require.NoError(t, err)
+		assert.Equal(t, []*model.LegalDocument{terms}, result.PendingDocuments)
+		require.NotNil(t, result.User)
+		assert.Nil(t, result.Identity)
+		assert.False(t, f.roles.hasRole(result.User.ID, f.analystRole), "as funções só são sincronizadas depois da aceitação")
+		assert.Zero(t, f.repo.users[result.User.ID].LoginCount)
+
+		f.legal.pending = nil
+		result, err = f.login(t, provider.ID, "ana@acme.co.ao", "Finance-Analysts")
+		require.NoError(t, err)
+		assert.Empty(t, result.PendingDocuments)
+		assert.True(t, f.roles.hasRole(result.User.ID, f.analystRole))
+	})
+
+	t.Run("usuário federado suspenso", func(t *testing.T) {
+		f := newSAMLFederationFixture(t)
+		provider := f.register(t, true)
+
+		result, err := f.login(t, provider.ID, "ana@acme.co.ao")
+		require.NoError(t, err)
+		f.repo.users[result.User.ID].Status = model.UserStatusSuspended
+
+		_, err = f.login(t, provider.ID, "ana@acme.co.ao")
+		assert.ErrorIs(t, err, application.ErrFederatedUserNotAllowed)
+	})
+}
+
+func TestSAMLFederationService_RoleSync(t *testing.T) {
+	f := newSAMLFederationFixture(t)
+	provider := f.register(t, true)
+
+	result, err := f.login(t, provider.ID, "ana@acme.co.ao", "Finance-Analysts", "audit-ao")
+	require.NoError(t, err)
+	userID := result.User.ID
+	assert.ElementsMatch(t, []uuid.UUID{f.analystRole, f.auditorRole}, result.RolesGranted)
+
+	// O grupo de auditoria deixa de constar na asserção: apenas essa função é retirada
+	result, err = f.login(t, provider.ID, "ana@acme.co.ao", "Finance-Analysts")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{f.auditorRole}, result.RolesRevoked)
+	assert.True(t, f.roles.hasRole(userID, f.analystRole))
+	assert.False(t, f.roles.hasRole(userID, f.auditorRole))
+
+	// Uma função atribuída manualmente não passa a ser gerida pela federação
+	require.NoError(t, f.roles.AssignUserToRole(context.Background(), f.tenantID, f.auditorRole, userID, time.Now(), nil, f.admin))
+	result, err = f.login(t, provider.ID, "ana@acme.co.ao", "Finance-Analysts", "audit-ao")
+	require.NoError(t, err)
+	assert.Empty(t, result.RolesGranted)
+
+	result, err = f.login(t, provider.ID, "ana@acme.co.ao")
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{f.analystRole}, result.RolesRevoked)
+	assert.True(t, f.roles.hasRole(userID, f.auditorRole), "a atribuição manual mantém-se")
+	assert.Empty(t, result.Identity.GrantedRoleIDs)
+}
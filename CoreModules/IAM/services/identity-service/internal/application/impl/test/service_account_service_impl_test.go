@@ -0,0 +1,668 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as contas de serviço (ServiceAccountService).
+ * Valida a autenticação por chave de API, cliente OIDC, certificado mTLS e token Kubernetes,
+ * a revogação e a validade das credenciais, a atribuição de funções e o relatório de inatividade.
+ */
+
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeServiceAccountRepository é um ServiceAccountRepository em memória
+type fakeServiceAccountRepository struct {
+	mu          sync.Mutex
+	accounts    map[uuid.UUID]*model.ServiceAccount
+	credentials map[uuid.UUID]*model.ServiceAccountCredential
+	roles       map[uuid.UUID]uuid.UUID
+	usage       []*model.ServiceAccountDailyUsage
+}
+
+func newFakeServiceAccountRepository() *fakeServiceAccountRepository {
+	return &fakeServiceAccountRepository{
+		accounts:    make(map[uuid.UUID]*model.ServiceAccount),
+		credentials: make(map[uuid.UUID]*model.ServiceAccountCredential),
+		roles:       make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func copyServiceAccount(account *model.ServiceAccount) *model.ServiceAccount {
+	copied := *account
+	copied.RoleIDs = append([]uuid.UUID{}, account.RoleIDs...)
+	if account.WorkloadIdentity != nil {
+		binding := *account.WorkloadIdentity
+		copied.WorkloadIdentity = &binding
+	}
+	return &copied
+}
+
+func (r *fakeServiceAccountRepository) ListServiceAccounts(ctx context.Context, tenantID uuid.UUID, filter model.ServiceAccountFilter) ([]*model.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var accounts []*model.ServiceAccount
+	for _, account := range r.accounts {
+		if account.TenantID == tenantID && (filter.Status == "" || account.Status == filter.Status) {
+			accounts = append(accounts, copyServiceAccount(account))
+		}
+	}
+	return accounts, nil
+}
+
+func (r *fakeServiceAccountRepository) GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[accountID]
+	if !ok || account.TenantID != tenantID {
+		return nil, model.ErrServiceAccountNotFound
+	}
+	return copyServiceAccount(account), nil
+}
+
+func (r *fakeServiceAccountRepository) FindByWorkloadIdentity(ctx context.Context, binding model.WorkloadIdentityBinding) (*model.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, account := range r.accounts {
+		if account.WorkloadIdentity != nil && *account.WorkloadIdentity == binding {
+			return copyServiceAccount(account), nil
+		}
+	}
+	return nil, model.ErrServiceAccountNotFound
+}
+
+func (r *fakeServiceAccountRepository) CreateServiceAccount(ctx context.Context, account *model.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.accounts {
+		if existing.TenantID == account.TenantID && existing.Name == account.Name {
+			return model.ErrServiceAccountConflict
+		}
+	}
+	r.accounts[account.ID] = copyServiceAccount(account)
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) UpdateServiceAccount(ctx context.Context, account *model.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.accounts[account.ID]
+	if !ok || existing.TenantID != account.TenantID {
+		return model.ErrServiceAccountNotFound
+	}
+	for _, other := range r.accounts {
+		if other.ID == account.ID {
+			continue
+		}
+		if other.TenantID == account.TenantID && other.Name == account.Name {
+			return model.ErrServiceAccountConflict
+		}
+		if account.WorkloadIdentity != nil && other.WorkloadIdentity != nil && *other.WorkloadIdentity == *account.WorkloadIdentity {
+			return model.ErrWorkloadIdentityConflict
+		}
+	}
+	updated := copyServiceAccount(account)
+	updated.RoleIDs = existing.RoleIDs
+	r.accounts[account.ID] = updated
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) BindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID, grantedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[accountID]
+	if !ok || account.TenantID != tenantID {
+		return model.ErrServiceAccountNotFound
+	}
+	if roleTenant, ok := r.roles[roleID]; !ok || roleTenant != tenantID {
+		return model.ErrServiceAccountRoleNotFound
+	}
+	for _, bound := range account.RoleIDs {
+		if bound == roleID {
+			return nil
+		}
+	}
+	account.RoleIDs = append(account.RoleIDs, roleID)
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) UnbindRole(ctx context.Context, tenantID, accountID, roleID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[accountID]
+	if !ok || account.TenantID != tenantID {
+		return model.ErrServiceAccountRoleNotBound
+	}
+	for i, bound := range account.RoleIDs {
+		if bound == roleID {
+			account.RoleIDs = append(account.RoleIDs[:i], account.RoleIDs[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrServiceAccountRoleNotBound
+}
+
+func (r *fakeServiceAccountRepository) ListCredentials(ctx context.Context, tenantID, accountID uuid.UUID) ([]*model.ServiceAccountCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var credentials []*model.ServiceAccountCredential
+	for _, credential := range r.credentials {
+		if credential.TenantID == tenantID && credential.ServiceAccountID == accountID {
+			copied := *credential
+			credentials = append(credentials, &copied)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeServiceAccountRepository) FindCredential(ctx context.Context, credentialType model.ServiceAccountCredentialType, identifier string) (*model.ServiceAccountCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, credential := range r.credentials {
+		if credential.Type != credentialType {
+			continue
+		}
+		if credential.KeyPrefix == identifier || credential.ClientID == identifier || credential.CertificateFingerprint == identifier {
+			copied := *credential
+			return &copied, nil
+		}
+	}
+	return nil, model.ErrServiceAccountCredentialNotFound
+}
+
+func (r *fakeServiceAccountRepository) CreateCredential(ctx context.Context, credential *model.ServiceAccountCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.credentials {
+		if existing.Type == model.CredentialTypeMTLSCert && existing.CertificateFingerprint == credential.CertificateFingerprint {
+			return model.ErrServiceAccountCredentialConflict
+		}
+	}
+	copied := *credential
+	r.credentials[credential.ID] = &copied
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) RevokeCredential(ctx context.Context, tenantID, accountID, credentialID, actorID uuid.UUID, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[credentialID]
+	if !ok || credential.TenantID != tenantID || credential.ServiceAccountID != accountID || credential.RevokedAt != nil {
+		return model.ErrServiceAccountCredentialNotFound
+	}
+	credential.RevokedBy = &actorID
+	credential.RevokedAt = &revokedAt
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) RecordUsage(ctx context.Context, usage *model.ServiceAccountUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usedAt := usage.UsedAt
+	r.accounts[usage.ServiceAccountID].LastUsedAt = &usedAt
+	if usage.CredentialID != nil {
+		r.credentials[*usage.CredentialID].LastUsedAt = &usedAt
+	}
+
+	day := usedAt.Truncate(24 * time.Hour)
+	for _, existing := range r.usage {
+		if existing.ServiceAccountID == usage.ServiceAccountID && existing.Day.Equal(day) && existing.Method == usage.Method {
+			existing.Authentications++
+			return nil
+		}
+	}
+	r.usage = append(r.usage, &model.ServiceAccountDailyUsage{
+		ServiceAccountID: usage.ServiceAccountID,
+		Day:              day,
+		Method:           usage.Method,
+		Authentications:  1,
+	})
+	return nil
+}
+
+func (r *fakeServiceAccountRepository) ListDailyUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]*model.ServiceAccountDailyUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var usage []*model.ServiceAccountDailyUsage
+	for _, day := range r.usage {
+		account, ok := r.accounts[day.ServiceAccountID]
+		if ok && account.TenantID == tenantID && !day.Day.Before(since) {
+			copied := *day
+			usage = append(usage, &copied)
+		}
+	}
+	return usage, nil
+}
+
+// seed grava diretamente uma conta, para simular contas antigas
+func (r *fakeServiceAccountRepository) seed(account *model.ServiceAccount) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accounts[account.ID] = copyServiceAccount(account)
+}
+
+// fakeKubernetesTokenReviewer associa tokens aos usuários de contas de serviço Kubernetes
+type fakeKubernetesTokenReviewer map[string]string
+
+func (r fakeKubernetesTokenReviewer) Review(ctx context.Context, token string) (string, error) {
+	username, ok := r[token]
+	if !ok {
+		return "", errors.New("token não autenticado")
+	}
+	return username, nil
+}
+
+type serviceAccountFixture struct {
+	service  application.ServiceAccountService
+	repo     *fakeServiceAccountRepository
+	codec    *fakeTokenExchangeCodec
+	tenantID uuid.UUID
+	actorID  uuid.UUID
+}
+
+func newServiceAccountFixture() *serviceAccountFixture {
+	repo := newFakeServiceAccountRepository()
+	codec := newFakeTokenExchangeCodec()
+	return &serviceAccountFixture{
+		service: impl.NewServiceAccountService(repo, codec, impl.ServiceAccountConfig{
+			Issuer:       "innovabiz-iam",
+			TokenTTL:     10 * time.Minute,
+			DormantAfter: 30 * 24 * time.Hour,
+			KubernetesClusters: map[string]application.KubernetesTokenReviewer{
+				"prod-luanda": fakeKubernetesTokenReviewer{
+					"payments-token": "system:serviceaccount:payments:gateway",
+					"batch-token":    "system:serviceaccount:batch:reconciler",
+					"user-token":     "maria.santos",
+				},
+			},
+		}),
+		repo:     repo,
+		codec:    codec,
+		tenantID: uuid.New(),
+		actorID:  uuid.New(),
+	}
+}
+
+func (f *serviceAccountFixture) createAccount(t *testing.T, name string) *model.ServiceAccount {
+	account, err := f.service.CreateServiceAccount(context.Background(), &application.SaveServiceAccountRequest{
+		TenantID: f.tenantID,
+		Name:     name,
+		ActorID:  f.actorID,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func (f *serviceAccountFixture) issue(t *testing.T, accountID uuid.UUID, credentialType model.ServiceAccountCredentialType) *application.IssuedServiceAccountCredential {
+	issued, err := f.service.IssueCredential(context.Background(), &application.IssueServiceAccountCredentialRequest{
+		TenantID:  f.tenantID,
+		AccountID: accountID,
+		Type:      credentialType,
+		ActorID:   f.actorID,
+	})
+	require.NoError(t, err)
+	return issued
+}
+
+// selfSignedCertificate gera um certificado de cliente autoassinado em PEM e retorna também o DER
+func selfSignedCertificate(t *testing.T, notAfter time.Time) (string, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "payments-gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), der
+}
+
+func TestServiceAccountAPIKeyAuthentication(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	account := f.createAccount(t, " Payments-Gateway ")
+	assert.Equal(t, "payments-gateway", account.Name)
+	assert.Equal(t, model.ServiceAccountActive, account.Status)
+
+	issued := f.issue(t, account.ID, model.CredentialTypeAPIKey)
+	assert.Contains(t, issued.Secret, impl.ServiceAccountAPIKeyPrefix+issued.Credential.KeyPrefix+"_")
+
+	token, err := f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{APIKey: issued.Secret})
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, token.ServiceAccountID)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, 600, token.ExpiresIn)
+
+	claims := f.codec.claims(token.AccessToken)
+	require.NotNil(t, claims)
+	assert.Equal(t, account.ID.String(), claims.Subject)
+	assert.Equal(t, f.tenantID.String(), claims.TenantID)
+	assert.Equal(t, "payments-gateway", claims.ClientID)
+	assert.Equal(t, "innovabiz-iam", claims.Issuer)
+
+	credentials, err := f.service.ListCredentials(ctx, f.tenantID, account.ID)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.NotNil(t, credentials[0].LastUsedAt)
+
+	_, err = f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{APIKey: issued.Secret + "x"})
+	assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed)
+	_, err = f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{APIKey: "not-a-key"})
+	assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed)
+
+	require.NoError(t, f.service.RevokeCredential(ctx, f.tenantID, account.ID, issued.Credential.ID, f.actorID))
+	_, err = f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{APIKey: issued.Secret})
+	assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed)
+	assert.ErrorIs(t, f.service.RevokeCredential(ctx, f.tenantID, account.ID, issued.Credential.ID, f.actorID),
+		application.ErrServiceAccountCredentialNotFound)
+}
+
+func TestServiceAccountOIDCClientAuthenticationAndDisabledAccount(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	account := f.createAccount(t, "risk-engine")
+	issued := f.issue(t, account.ID, model.CredentialTypeOIDCClient)
+	require.NotEmpty(t, issued.Credential.ClientID)
+
+	_, err := f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{
+		ClientID:     issued.Credential.ClientID,
+		ClientSecret: issued.Secret,
+	})
+	require.NoError(t, err)
+
+	_, err = f.service.UpdateServiceAccount(ctx, &application.SaveServiceAccountRequest{
+		TenantID:  f.tenantID,
+		AccountID: account.ID,
+		Name:      account.Name,
+		Status:    model.ServiceAccountDisabled,
+		ActorID:   f.actorID,
+	})
+	require.NoError(t, err)
+
+	_, err = f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{
+		ClientID:     issued.Credential.ClientID,
+		ClientSecret: issued.Secret,
+	})
+	assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed)
+}
+
+func TestServiceAccountMTLSCertificate(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	account := f.createAccount(t, "payments-gateway")
+
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+	certificatePEM, der := selfSignedCertificate(t, notAfter)
+	later := time.Now().Add(30 * 24 * time.Hour)
+	issued, err := f.service.IssueCredential(ctx, &application.IssueServiceAccountCredentialRequest{
+		TenantID:       f.tenantID,
+		AccountID:      account.ID,
+		Type:           model.CredentialTypeMTLSCert,
+		ExpiresAt:      &later,
+		CertificatePEM: certificatePEM,
+		ActorID:        f.actorID,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, issued.Secret)
+	assert.Equal(t, model.CertificateFingerprint(der), issued.Credential.CertificateFingerprint)
+	assert.Equal(t, "CN=payments-gateway", issued.Credential.CertificateSubject)
+	require.NotNil(t, issued.Credential.ExpiresAt)
+	assert.True(t, issued.Credential.ExpiresAt.Equal(notAfter), "a credencial expira com o certificado")
+
+	token, err := f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{
+		CertificateFingerprint: model.CertificateFingerprint(der),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, token.ServiceAccountID)
+
+	_, err = f.service.IssueCredential(ctx, &application.IssueServiceAccountCredentialRequest{
+		TenantID:       f.tenantID,
+		AccountID:      account.ID,
+		Type:           model.CredentialTypeMTLSCert,
+		CertificatePEM: certificatePEM,
+		ActorID:        f.actorID,
+	})
+	assert.ErrorIs(t, err, application.ErrServiceAccountCredentialConflict)
+
+	expiredPEM, _ := selfSignedCertificate(t, time.Now().Add(-time.Minute))
+	_, err = f.service.IssueCredential(ctx, &application.IssueServiceAccountCredentialRequest{
+		TenantID:       f.tenantID,
+		AccountID:      account.ID,
+		Type:           model.CredentialTypeMTLSCert,
+		CertificatePEM: expiredPEM,
+		ActorID:        f.actorID,
+	})
+	assert.ErrorIs(t, err, application.ErrInvalidServiceAccountCredential)
+}
+
+func TestServiceAccountKubernetesWorkloadIdentity(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	gateway := f.createAccount(t, "payments-gateway")
+	reconciler := f.createAccount(t, "reconciler")
+
+	binding := model.WorkloadIdentityBinding{Cluster: "prod-luanda", Namespace: "payments", ServiceAccount: "gateway"}
+	bound, err := f.service.BindWorkloadIdentity(ctx, f.tenantID, gateway.ID, binding, f.actorID)
+	require.NoError(t, err)
+	assert.Equal(t, &binding, bound.WorkloadIdentity)
+
+	_, err = f.service.BindWorkloadIdentity(ctx, f.tenantID, reconciler.ID, binding, f.actorID)
+	assert.ErrorIs(t, err, application.ErrWorkloadIdentityConflict)
+	_, err = f.service.BindWorkloadIdentity(ctx, f.tenantID, reconciler.ID,
+		model.WorkloadIdentityBinding{Cluster: "staging", Namespace: "batch", ServiceAccount: "reconciler"}, f.actorID)
+	assert.ErrorIs(t, err, application.ErrInvalidWorkloadIdentity)
+
+	token, err := f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{
+		KubernetesCluster: "prod-luanda",
+		KubernetesToken:   "payments-token",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, gateway.ID, token.ServiceAccountID)
+
+	for name, req := range map[string]*application.ServiceAccountAuthRequest{
+		"token recusado":       {KubernetesCluster: "prod-luanda", KubernetesToken: "forged"},
+		"conta não ligada":     {KubernetesCluster: "prod-luanda", KubernetesToken: "batch-token"},
+		"usuário não é SA":     {KubernetesCluster: "prod-luanda", KubernetesToken: "user-token"},
+		"cluster desconhecido": {KubernetesCluster: "staging", KubernetesToken: "payments-token"},
+	} {
+		_, err := f.service.Authenticate(ctx, req)
+		assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed, name)
+	}
+
+	unbound, err := f.service.UnbindWorkloadIdentity(ctx, f.tenantID, gateway.ID, f.actorID)
+	require.NoError(t, err)
+	assert.Nil(t, unbound.WorkloadIdentity)
+	_, err = f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{
+		KubernetesCluster: "prod-luanda",
+		KubernetesToken:   "payments-token",
+	})
+	assert.ErrorIs(t, err, application.ErrServiceAccountAuthenticationFailed)
+}
+
+func TestServiceAccountAuthenticationRequiresSingleMethod(t *testing.T) {
+	f := newServiceAccountFixture()
+
+	for name, req := range map[string]*application.ServiceAccountAuthRequest{
+		"nenhum método":         {},
+		"dois métodos":          {APIKey: "iamsa_abc_def", CertificateFingerprint: "ab"},
+		"client_id sem segredo": {ClientID: "sa-123"},
+		"token sem cluster":     {KubernetesToken: "payments-token"},
+	} {
+		_, err := f.service.Authenticate(context.Background(), req)
+		assert.ErrorIs(t, err, application.ErrInvalidServiceAccountAuthentication, name)
+	}
+}
+
+func TestServiceAccountRoleBindings(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	account := f.createAccount(t, "payments-gateway")
+	roleID := uuid.New()
+	f.repo.roles[roleID] = f.tenantID
+	otherTenantRole := uuid.New()
+	f.repo.roles[otherTenantRole] = uuid.New()
+
+	bound, err := f.service.BindRole(ctx, f.tenantID, account.ID, roleID, f.actorID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{roleID}, bound.RoleIDs)
+
+	again, err := f.service.BindRole(ctx, f.tenantID, account.ID, roleID, f.actorID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{roleID}, again.RoleIDs)
+
+	_, err = f.service.BindRole(ctx, f.tenantID, account.ID, otherTenantRole, f.actorID)
+	assert.ErrorIs(t, err, application.ErrServiceAccountRoleNotFound)
+	_, err = f.service.BindRole(ctx, uuid.New(), account.ID, roleID, f.actorID)
+	assert.ErrorIs(t, err, application.ErrServiceAccountNotFound)
+
+	unbound, err := f.service.UnbindRole(ctx, f.tenantID, account.ID, roleID, f.actorID)
+	require.NoError(t, err)
+	assert.Empty(t, unbound.RoleIDs)
+	_, err = f.service.UnbindRole(ctx, f.tenantID, account.ID, roleID, f.actorID)
+	assert.ErrorIs(t, err, application.ErrServiceAccountRoleNotBound)
+}
+
+func TestServiceAccountValidationAndConflicts(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	f.createAccount(t, "payments-gateway")
+
+	_, err := f.service.CreateServiceAccount(ctx, &application.SaveServiceAccountRequest{
+		TenantID: f.tenantID, Name: "payments-gateway", ActorID: f.actorID,
+	})
+	assert.ErrorIs(t, err, application.ErrServiceAccountConflict)
+
+	for _, name := range []string{"", "payments_gateway", "-gateway", "gateway.prod"} {
+		_, err := f.service.CreateServiceAccount(ctx, &application.SaveServiceAccountRequest{
+			TenantID: f.tenantID, Name: name, ActorID: f.actorID,
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidServiceAccount, name)
+	}
+
+	_, err = f.service.ListServiceAccounts(ctx, f.tenantID, model.ServiceAccountFilter{Status: "deleted"})
+	assert.ErrorIs(t, err, application.ErrInvalidServiceAccount)
+}
+
+func TestServiceAccountCredentialLimit(t *testing.T) {
+	f := newServiceAccountFixture()
+	account := f.createAccount(t, "payments-gateway")
+
+	var first *application.IssuedServiceAccountCredential
+	for i := 0; i < model.MaxServiceAccountCredentials; i++ {
+		issued := f.issue(t, account.ID, model.CredentialTypeAPIKey)
+		if first == nil {
+			first = issued
+		}
+	}
+
+	req := &application.IssueServiceAccountCredentialRequest{
+		TenantID: f.tenantID, AccountID: account.ID, Type: model.CredentialTypeAPIKey, ActorID: f.actorID,
+	}
+	_, err := f.service.IssueCredential(context.Background(), req)
+	assert.ErrorIs(t, err, application.ErrServiceAccountCredentialLimit)
+
+	// As credenciais revogadas não contam para o limite
+	require.NoError(t, f.service.RevokeCredential(context.Background(), f.tenantID, account.ID, first.Credential.ID, f.actorID))
+	_, err = f.service.IssueCredential(context.Background(), req)
+	assert.NoError(t, err)
+}
+
+func TestServiceAccountUsageReportFlagsDormantAccounts(t *testing.T) {
+	f := newServiceAccountFixture()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	active := f.createAccount(t, "payments-gateway")
+	issued := f.issue(t, active.ID, model.CredentialTypeAPIKey)
+	for i := 0; i < 3; i++ {
+		_, err := f.service.Authenticate(ctx, &application.ServiceAccountAuthRequest{APIKey: issued.Secret})
+		require.NoError(t, err)
+	}
+
+	lastUsed := now.AddDate(0, 0, -45)
+	stale := &model.ServiceAccount{
+		ID: uuid.New(), TenantID: f.tenantID, Name: "legacy-batch", Status: model.ServiceAccountActive,
+		LastUsedAt: &lastUsed, CreatedAt: now.AddDate(-1, 0, 0), UpdatedAt: now.AddDate(-1, 0, 0),
+	}
+	neverUsed := &model.ServiceAccount{
+		ID: uuid.New(), TenantID: f.tenantID, Name: "forgotten", Status: model.ServiceAccountActive,
+		CreatedAt: now.AddDate(0, 0, -60), UpdatedAt: now.AddDate(0, 0, -60),
+	}
+	disabled := &model.ServiceAccount{
+		ID: uuid.New(), TenantID: f.tenantID, Name: "retired", Status: model.ServiceAccountDisabled,
+		CreatedAt: now.AddDate(-2, 0, 0), UpdatedAt: now.AddDate(-2, 0, 0),
+	}
+	otherTenant := &model.ServiceAccount{
+		ID: uuid.New(), TenantID: uuid.New(), Name: "legacy-batch", Status: model.ServiceAccountActive,
+		CreatedAt: now.AddDate(-1, 0, 0), UpdatedAt: now.AddDate(-1, 0, 0),
+	}
+	for _, account := range []*model.ServiceAccount{stale, neverUsed, disabled, otherTenant} {
+		f.repo.seed(account)
+	}
+
+	report, err := f.service.UsageReport(ctx, f.tenantID, application.ServiceAccountUsageFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 30, report.DormantAfterDays)
+	assert.Equal(t, 2, report.DormantAccounts)
+	require.Len(t, report.Accounts, 4)
+	assert.Equal(t, []string{"retired", "forgotten", "legacy-batch", "payments-gateway"}, []string{
+		report.Accounts[0].Name, report.Accounts[1].Name, report.Accounts[2].Name, report.Accounts[3].Name,
+	})
+
+	gateway := report.Accounts[3]
+	assert.False(t, gateway.Dormant)
+	assert.Equal(t, 3, gateway.Authentications)
+	assert.Equal(t, 3, gateway.ByMethod[model.AuthMethodAPIKey])
+	assert.Equal(t, 1, gateway.ActiveDays)
+	assert.Equal(t, 0, gateway.IdleDays)
+	assert.False(t, report.Accounts[0].Dormant, "as contas desativadas não são inativas")
+	assert.Equal(t, 45, report.Accounts[2].IdleDays)
+
+	dormant, err := f.service.UsageReport(ctx, f.tenantID, application.ServiceAccountUsageFilter{DormantOnly: true})
+	require.NoError(t, err)
+	require.Len(t, dormant.Accounts, 2)
+	assert.Equal(t, "forgotten", dormant.Accounts[0].Name)
+	assert.Equal(t, "legacy-batch", dormant.Accounts[1].Name)
+
+	for _, days := range []int{-1, impl.MaxServiceAccountUsageDays + 1} {
+		_, err := f.service.UsageReport(ctx, f.tenantID, application.ServiceAccountUsageFilter{Days: days})
+		assert.ErrorIs(t, err, application.ErrInvalidServiceAccountUsageFilter)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para as políticas de sessão por tenant (SessionPolicyService).
+ * Valida os valores padrão e os limites de cada mercado, o cache das políticas e a
+ * recusa dos tokens longos, das sessões expiradas e das sessões inativas.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeSessionPolicyRepository é um SessionPolicyRepository em memória
+type fakeSessionPolicyRepository struct {
+	mu       sync.Mutex
+	policies map[uuid.UUID]*model.SessionPolicy
+	sessions map[string]*model.SessionActivity
+	loads    int
+	touches  int
+	touchErr error
+}
+
+func newFakeSessionPolicyRepository() *fakeSessionPolicyRepository {
+	return &fakeSessionPolicyRepository{
+		policies: make(map[uuid.UUID]*model.SessionPolicy),
+		sessions: make(map[string]*model.SessionActivity),
+	}
+}
+
+func (r *fakeSessionPolicyRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.SessionPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loads++
+	policy, ok := r.policies[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+func (r *fakeSessionPolicyRepository) SavePolicy(ctx context.Context, policy *model.SessionPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *policy
+	r.policies[policy.TenantID] = &copied
+	return nil
+}
+
+func (r *fakeSessionPolicyRepository) DeletePolicy(ctx context.Context, tenantID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.policies, tenantID)
+	return nil
+}
+
+func (r *fakeSessionPolicyRepository) TouchSession(ctx context.Context, activity *model.SessionActivity, idleSince time.Time) (*model.SessionActivity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.touchErr != nil {
+		return nil, r.touchErr
+	}
+	r.touches++
+	key := activity.TenantID.String() + "/" + activity.SessionID
+	current, ok := r.sessions[key]
+	if !ok {
+		copied := *activity
+		r.sessions[key] = &copied
+		result := copied
+		return &result, nil
+	}
+	if current.EndedAt == nil && current.LastSeenAt.Before(idleSince) {
+		endedAt := activity.LastSeenAt
+		current.EndedAt = &endedAt
+	}
+	if current.EndedAt == nil && activity.LastSeenAt.After(current.LastSeenAt) {
+		current.LastSeenAt = activity.LastSeenAt
+	}
+	result := *current
+	return &result, nil
+}
+
+// idle recua o último pedido da sessão, simulando a inatividade
+func (r *fakeSessionPolicyRepository) idle(tenantID uuid.UUID, sessionID string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[tenantID.String()+"/"+sessionID]; ok {
+		session.LastSeenAt = session.LastSeenAt.Add(-d)
+	}
+}
+
+type sessionPolicyFixture struct {
+	repo     *fakeSessionPolicyRepository
+	service  application.SessionPolicyService
+	tenantID uuid.UUID
+	adminID  uuid.UUID
+}
+
+func newSessionPolicyFixture() *sessionPolicyFixture {
+	f := &sessionPolicyFixture{
+		repo:     newFakeSessionPolicyRepository(),
+		tenantID: uuid.New(),
+		adminID:  uuid.New(),
+	}
+	config := impl.DefaultSessionPolicyConfig()
+	// Cada verificação regista a atividade, para que a inatividade simulada seja observada
+	config.ActivityWriteInterval = time.Nanosecond
+	f.service = impl.NewSessionPolicyService(f.repo, config)
+	return f
+}
+
+// financialEU configura o tenant como financeiro da UE com os valores padrão do mercado
+func (f *sessionPolicyFixture) financialEU(t *testing.T) *model.SessionPolicy {
+	policy, err := f.service.UpdatePolicy(context.Background(), &application.UpdateSessionPolicyRequest{
+		TenantID:        f.tenantID,
+		Market:          "EU",
+		FinancialTenant: true,
+		UpdatedBy:       f.adminID,
+	})
+	require.NoError(t, err)
+	return policy
+}
+
+func (f *sessionPolicyFixture) check(t *testing.T, check model.SessionCheck) *model.SessionDecision {
+	check.TenantID = f.tenantID
+	decision, err := f.service.CheckSession(context.Background(), &check)
+	require.NoError(t, err)
+	return decision
+}
+
+func TestSessionPolicyService_Defaults(t *testing.T) {
+	f := newSessionPolicyFixture()
+
+	policy, err := f.service.GetPolicy(context.Background(), f.tenantID, "eu")
+	require.NoError(t, err)
+	assert.True(t, policy.Default)
+	assert.False(t, policy.FinancialTenant)
+	assert.Equal(t, 15*time.Minute, policy.AccessTokenTTL())
+
+	eu := f.financialEU(t)
+	assert.False(t, eu.Default)
+	assert.Equal(t, 5*time.Minute, eu.AccessTokenTTL())
+	assert.Equal(t, 5*time.Minute, eu.IdleTimeout())
+	assert.Equal(t, 8*time.Hour, eu.AbsoluteLifetime())
+	assert.Equal(t, f.adminID, eu.UpdatedBy)
+
+	// Um mercado sem limites próprios usa os da UE para tenants financeiros
+	unknown := model.DefaultSessionPolicy(f.tenantID, "atlantis", true)
+	assert.Equal(t, eu.AccessTokenTTLSeconds, unknown.AccessTokenTTLSeconds)
+}
+
+func TestSessionPolicyService_MarketLimits(t *testing.T) {
+	f := newSessionPolicyFixture()
+	ctx := context.Background()
+
+	_, err := f.service.UpdatePolicy(ctx, &application.UpdateSessionPolicyRequest{
+		TenantID:              f.tenantID,
+		Market:                "eu",
+		FinancialTenant:       true,
+		AccessTokenTTLSeconds: 900,
+	})
+	assert.True(t, errors.Is(err, application.ErrSessionPolicyExceedsMarket))
+
+	// O mesmo valor é aceite a um tenant não financeiro
+	policy, err := f.service.UpdatePolicy(ctx, &application.UpdateSessionPolicyRequest{
+		TenantID:              f.tenantID,
+		Market:                "eu",
+		AccessTokenTTLSeconds: 900,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 900, policy.AccessTokenTTLSeconds)
+
+	tests := []struct {
+		name string
+		req  application.UpdateSessionPolicyRequest
+	}{
+		{"token de acesso mais longo que o de renovação", application.UpdateSessionPolicyRequest{AccessTokenTTLSeconds: 3600, RefreshTokenTTLSeconds: 1800}},
+		{"renovação mais longa que a sessão", application.UpdateSessionPolicyRequest{RefreshTokenTTLSeconds: 7200, AbsoluteLifetimeSeconds: 3600}},
+		{"inatividade abaixo do mínimo", application.UpdateSessionPolicyRequest{IdleTimeoutSeconds: 30}},
+		{"valor negativo", application.UpdateSessionPolicyRequest{IdleTimeoutSeconds: -60}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.TenantID = f.tenantID
+			_, err := f.service.UpdatePolicy(ctx, &req)
+			assert.True(t, errors.Is(err, application.ErrInvalidSessionPolicy), "erro inesperado: %v", err)
+		})
+	}
+}
+
+func TestSessionPolicyService_CacheInvalidation(t *testing.T) {
+	f := newSessionPolicyFixture()
+	ctx := context.Background()
+
+	_, err := f.service.GetPolicy(ctx, f.tenantID, "")
+	require.NoError(t, err)
+	_, err = f.service.GetPolicy(ctx, f.tenantID, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.repo.loads, "a política deve ficar em cache")
+
+	f.financialEU(t)
+	policy, err := f.service.GetPolicy(ctx, f.tenantID, "")
+	require.NoError(t, err)
+	assert.False(t, policy.Default)
+	assert.Equal(t, 2, f.repo.loads)
+
+	require.NoError(t, f.service.ResetPolicy(ctx, f.tenantID, f.adminID))
+	policy, err = f.service.GetPolicy(ctx, f.tenantID, "")
+	require.NoError(t, err)
+	assert.True(t, policy.Default)
+}
+
+func TestSessionPolicyService_TokenLifetime(t *testing.T) {
+	f := newSessionPolicyFixture()
+	f.financialEU(t)
+	now := time.Now().UTC()
+
+	decision := f.check(t, model.SessionCheck{IssuedAt: now, ExpiresAt: now.Add(5 * time.Minute), AuthTime: now})
+	assert.True(t, decision.Allowed)
+	assert.WithinDuration(t, now.Add(8*time.Hour), decision.SessionExpiresAt, time.Second)
+
+	// Token emitido com validade superior à da política
+	decision = f.check(t, model.SessionCheck{IssuedAt: now, ExpiresAt: now.Add(time.Hour), AuthTime: now})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.SessionRejectTokenLifetime, decision.Reason)
+
+	// Token sem exp emitido há mais tempo que a validade permitida
+	decision = f.check(t, model.SessionCheck{IssuedAt: now.Add(-10 * time.Minute), AuthTime: now.Add(-10 * time.Minute)})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.SessionRejectTokenLifetime, decision.Reason)
+}
+
+func TestSessionPolicyService_AbsoluteLifetime(t *testing.T) {
+	f := newSessionPolicyFixture()
+	f.financialEU(t)
+	now := time.Now().UTC()
+
+	decision := f.check(t, model.SessionCheck{IssuedAt: now, ExpiresAt: now.Add(time.Minute), AuthTime: now.Add(-9 * time.Hour)})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.SessionRejectAbsolute, decision.Reason)
+}
+
+func TestSessionPolicyService_IdleTimeout(t *testing.T) {
+	f := newSessionPolicyFixture()
+	f.financialEU(t)
+	now := time.Now().UTC()
+	check := model.SessionCheck{UserID: uuid.New(), SessionID: "sess-1", IssuedAt: now, ExpiresAt: now.Add(time.Minute), AuthTime: now}
+
+	assert.True(t, f.check(t, check).Allowed)
+	assert.True(t, f.check(t, check).Allowed)
+	assert.Equal(t, 2, f.repo.touches)
+
+	// Sem pedidos durante mais tempo que a inatividade permitida, a sessão termina de vez
+	f.repo.idle(f.tenantID, "sess-1", 10*time.Minute)
+	decision := f.check(t, check)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, model.SessionRejectIdle, decision.Reason)
+	assert.False(t, f.check(t, check).Allowed)
+
+	// Sem sessão identificada a inatividade não é verificada
+	check.SessionID = ""
+	assert.True(t, f.check(t, check).Allowed)
+	assert.Equal(t, 4, f.repo.touches)
+}
+
+func TestSessionPolicyService_ActivityFailure(t *testing.T) {
+	f := newSessionPolicyFixture()
+	f.repo.touchErr = errors.New("base de dados indisponível")
+	now := time.Now().UTC()
+
+	_, err := f.service.CheckSession(context.Background(), &model.SessionCheck{
+		TenantID: f.tenantID, SessionID: "sess-1", IssuedAt: now, AuthTime: now,
+	})
+	assert.Error(t, err)
+}
+
+func TestSessionPolicyService_Lifetimes(t *testing.T) {
+	f := newSessionPolicyFixture()
+	f.financialEU(t)
+	now := time.Now().UTC()
+
+	lifetimes, err := f.service.Lifetimes(context.Background(), f.tenantID, "", now)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(5*time.Minute), lifetimes.AccessTokenExpiresAt, time.Second)
+	assert.WithinDuration(t, now.Add(time.Hour), lifetimes.RefreshTokenExpiresAt, time.Second)
+	assert.Equal(t, 300, lifetimes.IdleTimeoutSeconds)
+
+	// Perto do fim da sessão nenhum token a ultrapassa
+	authTime := now.Add(-8*time.Hour + 2*time.Minute)
+	lifetimes, err = f.service.Lifetimes(context.Background(), f.tenantID, "", authTime)
+	require.NoError(t, err)
+	assert.Equal(t, authTime.Add(8*time.Hour), lifetimes.SessionExpiresAt)
+	assert.Equal(t, lifetimes.SessionExpiresAt, lifetimes.AccessTokenExpiresAt)
+	assert.Equal(t, lifetimes.SessionExpiresAt, lifetimes.RefreshTokenExpiresAt)
+}
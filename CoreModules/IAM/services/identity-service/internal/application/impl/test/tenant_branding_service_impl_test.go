@@ -0,0 +1,516 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a identidade visual e os modelos de email (TenantBrandingService).
+ * Valida a sanitização do HTML, as versões e a sua publicação e reposição, a escolha do
+ * modelo por idioma com o modelo padrão e a pré-visualização com valores de exemplo.
+ */
+
+package test
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+	"github.com/innovabiz/iam/internal/domain/repository"
+)
+
+// fakeTenantBrandingRepository é um TenantBrandingRepository em memória
+type fakeTenantBrandingRepository struct {
+	mu        sync.Mutex
+	brandings map[uuid.UUID]*model.TenantBranding
+	templates map[uuid.UUID]*model.EmailTemplate
+}
+
+func newFakeTenantBrandingRepository() *fakeTenantBrandingRepository {
+	return &fakeTenantBrandingRepository{
+		brandings: make(map[uuid.UUID]*model.TenantBranding),
+		templates: make(map[uuid.UUID]*model.EmailTemplate),
+	}
+}
+
+func (r *fakeTenantBrandingRepository) GetBranding(ctx context.Context, tenantID uuid.UUID) (*model.TenantBranding, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	branding, ok := r.brandings[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *branding
+	return &copied, nil
+}
+
+func (r *fakeTenantBrandingRepository) SaveBranding(ctx context.Context, branding *model.TenantBranding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *branding
+	r.brandings[branding.TenantID] = &copied
+	return nil
+}
+
+func (r *fakeTenantBrandingRepository) CreateEmailTemplate(ctx context.Context, template *model.EmailTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version := 0
+	for _, existing := range r.templates {
+		if existing.TenantID == template.TenantID && existing.Kind == template.Kind &&
+			existing.Locale == template.Locale && existing.Version > version {
+			version = existing.Version
+		}
+	}
+	template.Version = version + 1
+	copied := *template
+	r.templates[template.ID] = &copied
+	return nil
+}
+
+func (r *fakeTenantBrandingRepository) GetEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || template.TenantID != tenantID {
+		return nil, model.ErrEmailTemplateNotFound
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (r *fakeTenantBrandingRepository) ListEmailTemplates(ctx context.Context, tenantID uuid.UUID, filter repository.EmailTemplateFilter) ([]*model.EmailTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var templates []*model.EmailTemplate
+	for _, template := range r.templates {
+		if template.TenantID != tenantID ||
+			filter.Kind != "" && template.Kind != filter.Kind ||
+			filter.Locale != "" && template.Locale != filter.Locale ||
+			filter.Status != "" && template.Status != filter.Status {
+			continue
+		}
+		copied := *template
+		templates = append(templates, &copied)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Locale != templates[j].Locale {
+			return templates[i].Locale < templates[j].Locale
+		}
+		return templates[i].Version > templates[j].Version
+	})
+	return templates, nil
+}
+
+func (r *fakeTenantBrandingRepository) PublishEmailTemplate(ctx context.Context, tenantID, templateID, publishedBy uuid.UUID, publishedAt time.Time) (*model.EmailTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || template.TenantID != tenantID {
+		return nil, model.ErrEmailTemplateNotFound
+	}
+	for _, existing := range r.templates {
+		if existing.TenantID == tenantID && existing.Kind == template.Kind && existing.Locale == template.Locale &&
+			existing.Status == model.EmailTemplatePublished {
+			existing.Status = model.EmailTemplateArchived
+		}
+	}
+	template.Status = model.EmailTemplatePublished
+	template.PublishedBy = &publishedBy
+	template.PublishedAt = &publishedAt
+	copied := *template
+	return &copied, nil
+}
+
+func (r *fakeTenantBrandingRepository) ArchiveEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[templateID]
+	if !ok || template.TenantID != tenantID {
+		return nil, model.ErrEmailTemplateNotFound
+	}
+	template.Status = model.EmailTemplateArchived
+	copied := *template
+	return &copied, nil
+}
+
+// tenantBrandingFixture agrupa o serviço e o tenant dos testes
+type tenantBrandingFixture struct {
+	service  application.TenantBrandingService
+	tenantID uuid.UUID
+	actorID  uuid.UUID
+}
+
+func newTenantBrandingFixture() *tenantBrandingFixture {
+	return &tenantBrandingFixture{
+		service:  impl.NewTenantBrandingService(newFakeTenantBrandingRepository()),
+		tenantID: uuid.New(),
+		actorID:  uuid.New(),
+	}
+}
+
+func (f *tenantBrandingFixture) create(t *testing.T, kind model.EmailTemplateKind, locale, subject, html string) *model.EmailTemplate {
+	t.Helper()
+
+	template, err := f.service.CreateEmailTemplate(context.Background(), &application.CreateEmailTemplateRequest{
+		TenantID:  f.tenantID,
+		Kind:      kind,
+		Locale:    locale,
+		Subject:   subject,
+		HTMLBody:  html,
+		CreatedBy: f.actorID,
+	})
+	require.NoError(t, err)
+	return template
+}
+
+func (f *tenantBrandingFixture) render(t *testing.T, kind model.EmailTemplateKind, locale string) *model.RenderedEmail {
+	t.Helper()
+
+	rendered, err := f.service.RenderEmail(context.Background(), f.tenantID, kind, locale, &model.EmailTemplateData{
+		Name:      "Ana Silva",
+		Email:     "ana.silva@example.com",
+		ActionURL: "https://app.innovabiz.test/invite?token=abc",
+		ExpiresAt: "17/10/2026 09:30 WAT",
+		InvitedBy: "Carlos Mendes",
+	})
+	require.NoError(t, err)
+	return rendered
+}
+
+func TestTenantBrandingService_Branding(t *testing.T) {
+	f := newTenantBrandingFixture()
+	ctx := context.Background()
+
+	// Sem identidade visual configurada é usada a identidade visual padrão
+	branding, err := f.service.GetBranding(ctx, f.tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultTenantBrandingName, branding.DisplayName)
+
+	testCases := []struct {
+		name string
+		req  application.UpdateTenantBrandingRequest
+	}{
+		{"nome obrigatório", application.UpdateTenantBrandingRequest{DisplayName: " "}},
+		{"logótipo sem https", application.UpdateTenantBrandingRequest{DisplayName: "Banco", LogoURL: "http://cdn.example.com/logo.png"}},
+		{"logótipo javascript", application.UpdateTenantBrandingRequest{DisplayName: "Banco", LogoURL: "javascript:alert(1)"}},
+		{"cor inválida", application.UpdateTenantBrandingRequest{DisplayName: "Banco", PrimaryColor: "red;background:url(x)"}},
+		{"email de suporte inválido", application.UpdateTenantBrandingRequest{DisplayName: "Banco", SupportEmail: "suporte"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			req.TenantID = f.tenantID
+			_, err := f.service.UpdateBranding(ctx, &req)
+			assert.ErrorIs(t, err, application.ErrInvalidTenantBranding)
+		})
+	}
+
+	updated, err := f.service.UpdateBranding(ctx, &application.UpdateTenantBrandingRequest{
+		TenantID:     f.tenantID,
+		DisplayName:  "Banco Exemplo",
+		LogoURL:      "https://cdn.example.com/logo.png",
+		PrimaryColor: "#C8102E",
+		SupportEmail: "suporte@example.com",
+		UpdatedBy:    f.actorID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Banco Exemplo", updated.DisplayName)
+
+	// A identidade visual é aplicada aos emails padrão
+	rendered := f.render(t, model.EmailTemplateKindInvite, "pt-PT")
+	assert.True(t, rendered.Builtin)
+	assert.Contains(t, rendered.HTML, "Banco Exemplo")
+	assert.Contains(t, rendered.HTML, "#C8102E")
+	assert.Contains(t, rendered.HTML, "https://cdn.example.com/logo.png")
+	assert.Contains(t, rendered.HTML, "suporte@example.com")
+}
+
+func TestTenantBrandingService_Sanitization(t *testing.T) {
+	f := newTenantBrandingFixture()
+
+	template := f.create(t, model.EmailTemplateKindInvite, "pt-PT", "Convite de {{.InvitedBy}}",
+		`<p onclick="steal()">Olá {{.Name}}</p>`+
+			`<script>alert(1)</script>`+
+			`<a href="javascript:alert(1)">perigo</a>`+
+			`<a href="{{.ActionURL}}" style="color:{{.Brand.PrimaryColor}}">Aceitar</a>`+
+			`<img src="https://cdn.example.com/x.png" onerror="steal()">`+
+			`<iframe src="https://evil.example.com"></iframe>`)
+
+	assert.NotContains(t, template.HTMLBody, "<script")
+	assert.NotContains(t, template.HTMLBody, "alert(1)")
+	assert.NotContains(t, template.HTMLBody, "onclick")
+	assert.NotContains(t, template.HTMLBody, "onerror")
+	assert.NotContains(t, template.HTMLBody, "javascript:")
+	assert.NotContains(t, template.HTMLBody, "<iframe")
+	assert.Contains(t, template.HTMLBody, `<a href="{{.ActionURL}}"`)
+	assert.Contains(t, template.HTMLBody, `<img src="https://cdn.example.com/x.png">`)
+
+	// As variáveis são escapadas conforme o contexto, também quando o valor é um URL perigoso
+	rendered, err := f.service.PreviewEmail(context.Background(), &application.PreviewEmailRequest{
+		TenantID:   f.tenantID,
+		TemplateID: &template.ID,
+		Variables: map[string]string{
+			"Name":      `<b>Ana</b>`,
+			"ActionURL": "javascript:alert(2)",
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.HTML, "&lt;b&gt;Ana&lt;/b&gt;")
+	assert.NotContains(t, rendered.HTML, "javascript:")
+	assert.Equal(t, "Convite de Carlos Mendes", rendered.Subject)
+
+	t.Run("literal antes da variável no link", func(t *testing.T) {
+		created, err := f.service.CreateEmailTemplate(context.Background(), &application.CreateEmailTemplateRequest{
+			TenantID: f.tenantID,
+			Kind:     model.EmailTemplateKindInvite,
+			Locale:   "pt-PT",
+			Subject:  "Convite",
+			HTMLBody: `<a href="java{{.Name}}script:alert(1)">x</a><p>{{.Name}}</p>`,
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, created.HTMLBody, "href")
+	})
+
+	t.Run("sem conteúdo permitido", func(t *testing.T) {
+		_, err := f.service.CreateEmailTemplate(context.Background(), &application.CreateEmailTemplateRequest{
+			TenantID: f.tenantID,
+			Kind:     model.EmailTemplateKindInvite,
+			Locale:   "pt-PT",
+			Subject:  "Convite",
+			HTMLBody: `<script>alert(1)</script>`,
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidEmailTemplate)
+	})
+}
+
+func TestTenantBrandingService_Validation(t *testing.T) {
+	f := newTenantBrandingFixture()
+
+	testCases := []struct {
+		name string
+		req  application.CreateEmailTemplateRequest
+	}{
+		{"email desconhecido", application.CreateEmailTemplateRequest{Kind: "welcome", Locale: "pt-PT", Subject: "x", HTMLBody: "<p>x</p>"}},
+		{"idioma inválido", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindInvite, Locale: "português", Subject: "x", HTMLBody: "<p>x</p>"}},
+		{"assunto com quebra de linha", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindInvite, Locale: "pt-PT", Subject: "x\r\nBcc: a@b.c", HTMLBody: "<p>x</p>"}},
+		{"variável desconhecida", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindInvite, Locale: "pt-PT", Subject: "x", HTMLBody: "<p>{{.Nome}}</p>"}},
+		{"variável de outro email", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindPasswordReset, Locale: "pt-PT", Subject: "{{.InvitedBy}}", HTMLBody: "<p>x</p>"}},
+		{"campo desconhecido da identidade visual", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindInvite, Locale: "pt-PT", Subject: "x", HTMLBody: "<p>{{.Brand.Slogan}}</p>"}},
+		{"modelo inválido", application.CreateEmailTemplateRequest{Kind: model.EmailTemplateKindInvite, Locale: "pt-PT", Subject: "x", HTMLBody: "<p>{{.Name</p>"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			req.TenantID = f.tenantID
+			_, err := f.service.CreateEmailTemplate(context.Background(), &req)
+			assert.ErrorIs(t, err, application.ErrInvalidEmailTemplate)
+		})
+	}
+
+	variables := f.service.ListVariables(context.Background())
+	require.Len(t, variables, len(model.EmailTemplateKinds))
+	names := make(map[model.EmailTemplateKind][]string)
+	for _, kind := range variables {
+		for _, variable := range kind.Variables {
+			names[kind.Kind] = append(names[kind.Kind], variable.Name)
+		}
+	}
+	assert.Contains(t, names[model.EmailTemplateKindInvite], "InvitedBy")
+	assert.NotContains(t, names[model.EmailTemplateKindPasswordReset], "InvitedBy")
+	assert.Contains(t, names[model.EmailTemplateKindMFAEnrollment], "Method")
+	assert.Contains(t, names[model.EmailTemplateKindPasswordReset], "Brand.LogoURL")
+}
+
+func TestTenantBrandingService_VersionsAndRollback(t *testing.T) {
+	f := newTenantBrandingFixture()
+	ctx := context.Background()
+
+	v1 := f.create(t, model.EmailTemplateKindInvite, "pt-PT", "Convite v1", "<p>v1 {{.Name}}</p>")
+	v2 := f.create(t, model.EmailTemplateKindInvite, "pt-PT", "Convite v2", "<p>v2 {{.Name}}</p>")
+	assert.Equal(t, 1, v1.Version)
+	assert.Equal(t, 2, v2.Version)
+	assert.Equal(t, model.EmailTemplateDraft, v2.Status)
+
+	// Os rascunhos não são enviados
+	assert.True(t, f.render(t, model.EmailTemplateKindInvite, "pt-PT").Builtin)
+
+	published, err := f.service.PublishEmailTemplate(ctx, f.tenantID, v1.ID, f.actorID)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmailTemplatePublished, published.Status)
+	require.NotNil(t, published.PublishedBy)
+	assert.Equal(t, f.actorID, *published.PublishedBy)
+	assert.Equal(t, "Convite v1", f.render(t, model.EmailTemplateKindInvite, "pt-PT").Subject)
+
+	// Publicar a v2 arquiva a v1
+	_, err = f.service.PublishEmailTemplate(ctx, f.tenantID, v2.ID, f.actorID)
+	require.NoError(t, err)
+	rendered := f.render(t, model.EmailTemplateKindInvite, "pt-PT")
+	assert.Equal(t, "Convite v2", rendered.Subject)
+	require.NotNil(t, rendered.TemplateID)
+	assert.Equal(t, v2.ID, *rendered.TemplateID)
+	assert.Equal(t, 2, rendered.Version)
+
+	archived, err := f.service.GetEmailTemplate(ctx, f.tenantID, v1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.EmailTemplateArchived, archived.Status)
+
+	// Voltar a publicar a v1 repõe-na
+	_, err = f.service.PublishEmailTemplate(ctx, f.tenantID, v1.ID, f.actorID)
+	require.NoError(t, err)
+	assert.Equal(t, "Convite v1", f.render(t, model.EmailTemplateKindInvite, "pt-PT").Subject)
+
+	published, err = f.service.PublishEmailTemplate(ctx, f.tenantID, v1.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, f.actorID, *published.PublishedBy, "publicar a versão publicada não tem efeito")
+
+	templates, err := f.service.ListEmailTemplates(ctx, &application.ListEmailTemplatesRequest{
+		TenantID: f.tenantID,
+		Status:   model.EmailTemplatePublished,
+	})
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, v1.ID, templates[0].ID)
+
+	// Arquivar a versão publicada repõe o modelo padrão
+	_, err = f.service.ArchiveEmailTemplate(ctx, f.tenantID, v1.ID, f.actorID)
+	require.NoError(t, err)
+	assert.True(t, f.render(t, model.EmailTemplateKindInvite, "pt-PT").Builtin)
+
+	_, err = f.service.PublishEmailTemplate(ctx, f.tenantID, uuid.New(), f.actorID)
+	assert.ErrorIs(t, err, application.ErrEmailTemplateNotFound)
+	_, err = f.service.GetEmailTemplate(ctx, uuid.New(), v1.ID)
+	assert.ErrorIs(t, err, application.ErrEmailTemplateNotFound)
+
+	t.Run("filtros inválidos", func(t *testing.T) {
+		_, err := f.service.ListEmailTemplates(ctx, &application.ListEmailTemplatesRequest{TenantID: f.tenantID, Status: "deleted"})
+		assert.ErrorIs(t, err, application.ErrInvalidEmailTemplate)
+		_, err = f.service.ListEmailTemplates(ctx, &application.ListEmailTemplatesRequest{TenantID: f.tenantID, Kind: "welcome"})
+		assert.ErrorIs(t, err, application.ErrInvalidEmailTemplate)
+	})
+}
+
+func TestTenantBrandingService_LocaleFallback(t *testing.T) {
+	f := newTenantBrandingFixture()
+	ctx := context.Background()
+
+	general := f.create(t, model.EmailTemplateKindPasswordReset, "pt", "Redefinição (pt)", "<p>{{.ActionURL}}</p>")
+	brazil := f.create(t, model.EmailTemplateKindPasswordReset, "pt_br", "Redefinição (pt-BR)", "<p>{{.ActionURL}}</p>")
+	assert.Equal(t, "pt-BR", brazil.Locale)
+	for _, template := range []*model.EmailTemplate{general, brazil} {
+		_, err := f.service.PublishEmailTemplate(ctx, f.tenantID, template.ID, f.actorID)
+		require.NoError(t, err)
+	}
+
+	// O idioma exato prevalece sobre a mesma língua
+	assert.Equal(t, "Redefinição (pt-BR)", f.render(t, model.EmailTemplateKindPasswordReset, "pt-BR").Subject)
+	assert.Equal(t, "Redefinição (pt)", f.render(t, model.EmailTemplateKindPasswordReset, "pt-AO").Subject)
+
+	// Sem versão publicada na língua é usado o modelo padrão do idioma
+	french := f.render(t, model.EmailTemplateKindPasswordReset, "fr-CA")
+	assert.True(t, french.Builtin)
+	assert.Equal(t, "fr", french.Locale)
+	assert.True(t, strings.HasPrefix(french.Subject, "Réinitialisation"))
+
+	// Sem modelo padrão no idioma é usado o inglês
+	german := f.render(t, model.EmailTemplateKindPasswordReset, "de-DE")
+	assert.True(t, german.Builtin)
+	assert.Equal(t, "en", german.Locale)
+
+	_, err := f.service.RenderEmail(ctx, f.tenantID, "welcome", "pt-PT", &model.EmailTemplateData{})
+	assert.ErrorIs(t, err, application.ErrInvalidEmailTemplate)
+
+	// Os modelos padrão de todos os emails e idiomas compilam com todas as variáveis
+	builtins := newTenantBrandingFixture()
+	for _, kind := range model.EmailTemplateKinds {
+		for _, locale := range []string{"pt-PT", "pt-BR", "en", "es", "fr"} {
+			builtin := model.BuiltinEmailTemplate(kind, locale)
+			assert.Equal(t, locale, builtin.Locale)
+			rendered := builtins.render(t, kind, locale)
+			assert.True(t, rendered.Builtin)
+			assert.NotEmpty(t, rendered.Text, "%s/%s", kind, locale)
+			assert.Contains(t, rendered.HTML, "https://app.innovabiz.test/invite?token=abc", "%s/%s", kind, locale)
+		}
+	}
+}
+
+func TestTenantBrandingService_Preview(t *testing.T) {
+	f := newTenantBrandingFixture()
+	ctx := context.Background()
+
+	t.Run("modelo padrão com valores de exemplo", func(t *testing.T) {
+		rendered, err := f.service.PreviewEmail(ctx, &application.PreviewEmailRequest{
+			TenantID: f.tenantID,
+			Kind:     model.EmailTemplateKindMFAEnrollment,
+			Locale:   "en",
+		})
+		require.NoError(t, err)
+		assert.True(t, rendered.Builtin)
+		assert.Contains(t, rendered.HTML, "TOTP")
+		assert.Contains(t, rendered.Text, "Ana Silva")
+	})
+
+	t.Run("modelo enviado sem o gravar", func(t *testing.T) {
+		rendered, err := f.service.PreviewEmail(ctx, &application.PreviewEmailRequest{
+			TenantID:  f.tenantID,
+			Kind:      model.EmailTemplateKindInvite,
+			Locale:    "pt-PT",
+			Subject:   "{{.Brand.Name}}: convite de {{.InvitedBy}}",
+			HTMLBody:  `<p style="color:{{.Brand.PrimaryColor}}">{{.Name}}</p><script>x</script>`,
+			TextBody:  "{{.Name}} {{.ActionURL}}",
+			Variables: map[string]string{"Name": "João", "InvitedBy": "Maria\r\nBcc: x@example.com"},
+			Branding:  &model.TenantBranding{DisplayName: "Banco Exemplo", PrimaryColor: "#112233"},
+		})
+		require.NoError(t, err)
+		assert.False(t, rendered.Builtin)
+		assert.Nil(t, rendered.TemplateID)
+		assert.Equal(t, "Banco Exemplo: convite de Maria Bcc: x@example.com", rendered.Subject)
+		assert.Contains(t, rendered.HTML, "João")
+		assert.Contains(t, rendered.HTML, "#112233")
+		assert.NotContains(t, rendered.HTML, "<script")
+		assert.True(t, strings.HasPrefix(rendered.Text, "João https://"))
+
+		templates, err := f.service.ListEmailTemplates(ctx, &application.ListEmailTemplatesRequest{TenantID: f.tenantID})
+		require.NoError(t, err)
+		assert.Empty(t, templates)
+	})
+
+	t.Run("variável desconhecida", func(t *testing.T) {
+		_, err := f.service.PreviewEmail(ctx, &application.PreviewEmailRequest{
+			TenantID:  f.tenantID,
+			Kind:      model.EmailTemplateKindInvite,
+			Variables: map[string]string{"Nome": "João"},
+		})
+		assert.ErrorIs(t, err, application.ErrUnknownEmailTemplateVariable)
+	})
+
+	t.Run("identidade visual inválida", func(t *testing.T) {
+		_, err := f.service.PreviewEmail(ctx, &application.PreviewEmailRequest{
+			TenantID: f.tenantID,
+			Kind:     model.EmailTemplateKindInvite,
+			Branding: &model.TenantBranding{DisplayName: "Banco", LogoURL: "javascript:alert(1)"},
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidTenantBranding)
+	})
+
+	t.Run("versão inexistente", func(t *testing.T) {
+		missing := uuid.New()
+		_, err := f.service.PreviewEmail(ctx, &application.PreviewEmailRequest{TenantID: f.tenantID, TemplateID: &missing})
+		assert.ErrorIs(t, err, application.ErrEmailTemplateNotFound)
+	})
+}
@@ -0,0 +1,493 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a exportação de dados de tenants (TenantExportService).
+ * Valida os formatos, o tratamento de dados pessoais por mercado, a retoma após falhas
+ * sem duplicação de linhas e a assinatura do manifesto.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeTenantExportRepository é um TenantExportRepository em memória
+// As linhas de cada secção são lidas por posição, usando o índice como cursor
+type fakeTenantExportRepository struct {
+	mu       sync.Mutex
+	exports  map[uuid.UUID]*model.TenantExport
+	sections map[model.TenantExportSection][]model.TenantExportRecord
+}
+
+func newFakeTenantExportRepository() *fakeTenantExportRepository {
+	return &fakeTenantExportRepository{
+		exports:  make(map[uuid.UUID]*model.TenantExport),
+		sections: make(map[model.TenantExportSection][]model.TenantExportRecord),
+	}
+}
+
+func copyTenantExport(export *model.TenantExport) *model.TenantExport {
+	copied := *export
+	copied.Progress = append([]model.TenantExportSectionProgress(nil), export.Progress...)
+	return &copied
+}
+
+func (r *fakeTenantExportRepository) Create(ctx context.Context, export *model.TenantExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.exports {
+		if stored.TenantID == export.TenantID && stored.IsActive() {
+			return model.ErrTenantExportInProgress
+		}
+	}
+	r.exports[export.ID] = copyTenantExport(export)
+	return nil
+}
+
+func (r *fakeTenantExportRepository) Get(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	export, ok := r.exports[exportID]
+	if !ok || export.TenantID != tenantID {
+		return nil, model.ErrTenantExportNotFound
+	}
+	return copyTenantExport(export), nil
+}
+
+func (r *fakeTenantExportRepository) List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.TenantExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var exports []*model.TenantExport
+	for _, export := range r.exports {
+		if export.TenantID == tenantID {
+			exports = append(exports, copyTenantExport(export))
+		}
+	}
+	return exports, nil
+}
+
+func (r *fakeTenantExportRepository) ListActive(ctx context.Context, limit int) ([]*model.TenantExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var exports []*model.TenantExport
+	for _, export := range r.exports {
+		if export.IsActive() {
+			exports = append(exports, copyTenantExport(export))
+		}
+	}
+	return exports, nil
+}
+
+func (r *fakeTenantExportRepository) Save(ctx context.Context, export *model.TenantExport, expectedVersion int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.exports[export.ID]
+	if !ok || stored.Version != expectedVersion {
+		return model.ErrTenantExportConflict
+	}
+	export.Version = expectedVersion + 1
+	r.exports[export.ID] = copyTenantExport(export)
+	return nil
+}
+
+func (r *fakeTenantExportRepository) ReadSection(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	section model.TenantExportSection,
+	cursor string,
+	limit int,
+) ([]model.TenantExportRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := 0
+	if cursor != "" {
+		index, err := strconv.Atoi(cursor)
+		if err != nil {
+			return nil, err
+		}
+		start = index + 1
+	}
+	records := r.sections[section]
+	if start >= len(records) {
+		return nil, nil
+	}
+	end := start + limit
+	if end > len(records) {
+		end = len(records)
+	}
+	return records[start:end], nil
+}
+
+// addRecords acrescenta linhas a uma secção, com valores alinhados com as suas colunas
+func (r *fakeTenantExportRepository) addRecords(section model.TenantExportSection, rows ...map[string]interface{}) {
+	columns := section.Columns()
+	for _, row := range rows {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = row[column.Name]
+		}
+		key := strconv.Itoa(len(r.sections[section]))
+		r.sections[section] = append(r.sections[section], model.TenantExportRecord{Key: key, Values: values})
+	}
+}
+
+// fakeTenantExportArchive é um TenantExportArchive em memória
+// failAppends faz falhar os acréscimos seguintes depois de gravar metade dos dados
+type fakeTenantExportArchive struct {
+	mu          sync.Mutex
+	files       map[string][]byte
+	failAfter   int
+	failAppends int
+	appends     int
+}
+
+func newFakeTenantExportArchive() *fakeTenantExportArchive {
+	return &fakeTenantExportArchive{files: make(map[string][]byte)}
+}
+
+func (a *fakeTenantExportArchive) Append(ctx context.Context, exportID uuid.UUID, name string, data []byte) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := exportID.String() + "/" + name
+	a.appends++
+	if a.failAppends > 0 && a.appends > a.failAfter {
+		a.failAppends--
+		a.files[path] = append(a.files[path], data[:len(data)/2]...)
+		return 0, errors.New("disco indisponível")
+	}
+	a.files[path] = append(a.files[path], data...)
+	return int64(len(a.files[path])), nil
+}
+
+func (a *fakeTenantExportArchive) Truncate(ctx context.Context, exportID uuid.UUID, name string, size int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := exportID.String() + "/" + name
+	if int64(len(a.files[path])) < size {
+		return fmt.Errorf("ficheiro %s menor do que %d", name, size)
+	}
+	a.files[path] = append([]byte{}, a.files[path][:size]...)
+	return nil
+}
+
+func (a *fakeTenantExportArchive) Open(ctx context.Context, exportID uuid.UUID, name string) (io.ReadCloser, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	data, ok := a.files[exportID.String()+"/"+name]
+	if !ok {
+		return nil, errors.New("ficheiro inexistente")
+	}
+	return io.NopCloser(bytes.NewReader(append([]byte{}, data...))), nil
+}
+
+func (a *fakeTenantExportArchive) file(exportID uuid.UUID, name string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return string(a.files[exportID.String()+"/"+name])
+}
+
+// tenantExportFixture agrupa o serviço e os seus colaboradores em memória
+type tenantExportFixture struct {
+	service    application.TenantExportService
+	repo       *fakeTenantExportRepository
+	archive    *fakeTenantExportArchive
+	publicKey  ed25519.PublicKey
+	tenantID   uuid.UUID
+	operatorID uuid.UUID
+}
+
+func newTenantExportFixture(t *testing.T, batchSize int) *tenantExportFixture {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	repo := newFakeTenantExportRepository()
+	archive := newFakeTenantExportArchive()
+	createdAt := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		repo.addRecords(model.TenantExportSectionUsers, map[string]interface{}{
+			"id":         uuid.New().String(),
+			"username":   fmt.Sprintf("utilizador%d", i),
+			"email":      fmt.Sprintf("utilizador%d@banco.ao", i),
+			"first_name": "Ana",
+			"last_name":  "Silva",
+			"status":     "active",
+			"metadata":   json.RawMessage(`{"department":"Tesouraria","manager":{"email":"chefe@banco.ao"}}`),
+			"created_at": createdAt,
+		})
+	}
+	repo.addRecords(model.TenantExportSectionRoles,
+		map[string]interface{}{"id": uuid.New().String(), "name": "Operador", "is_system": false, "created_at": createdAt},
+		map[string]interface{}{"id": uuid.New().String(), "name": "Auditor", "is_system": true, "created_at": createdAt},
+	)
+	repo.addRecords(model.TenantExportSectionAuditLogs, map[string]interface{}{
+		"id":          uuid.New().String(),
+		"event_type":  "USER_UPDATED",
+		"entity_type": "user",
+		"ip_address":  "10.20.30.40",
+		"new_value":   json.RawMessage(`{"email":"utilizador0@banco.ao","status":"active"}`),
+		"created_at":  createdAt,
+	})
+
+	return &tenantExportFixture{
+		service: impl.NewTenantExportService(repo, archive, impl.TenantExportConfig{
+			BatchSize:  batchSize,
+			SigningKey: privateKey,
+			KeyID:      "tenant-export-test",
+		}),
+		repo:       repo,
+		archive:    archive,
+		publicKey:  publicKey,
+		tenantID:   uuid.New(),
+		operatorID: uuid.New(),
+	}
+}
+
+func (f *tenantExportFixture) start(t *testing.T, market string, format model.TenantExportFormat, mode model.TenantExportPIIMode) *model.TenantExport {
+	t.Helper()
+
+	export, err := f.service.Start(context.Background(), &application.StartTenantExportRequest{
+		TenantID:    f.tenantID,
+		RequestedBy: f.operatorID,
+		Market:      market,
+		Format:      format,
+		PIIMode:     mode,
+	})
+	require.NoError(t, err)
+	return export
+}
+
+// jsonLines decodifica um ficheiro JSON Lines
+func jsonLines(t *testing.T, data string) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+// TestTenantExportJSONWithSignedManifest valida o arquivo completo e a verificação do manifesto
+func TestTenantExportJSONWithSignedManifest(t *testing.T) {
+	f := newTenantExportFixture(t, 2)
+	ctx := context.Background()
+
+	export := f.start(t, "usa", model.TenantExportFormatJSON, "")
+	assert.Equal(t, model.TenantExportPIIInclude, export.PIIMode)
+	assert.Equal(t, model.TenantExportStatusPending, export.Status)
+
+	export, err := f.service.Run(ctx, f.tenantID, export.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.TenantExportStatusCompleted, export.Status)
+	require.NotNil(t, export.Manifest)
+	assert.Len(t, export.Manifest.Files, len(model.TenantExportSections))
+
+	users := jsonLines(t, f.archive.file(export.ID, "users.jsonl"))
+	require.Len(t, users, 5)
+	assert.Equal(t, "utilizador0@banco.ao", users[0]["email"])
+	assert.Equal(t, "2025-03-10T09:30:00Z", users[0]["created_at"])
+	assert.Nil(t, users[0]["deleted_at"])
+	assert.True(t, strings.HasPrefix(f.archive.file(export.ID, "users.jsonl"), `{"id":`), "campos pela ordem das colunas")
+	assert.Empty(t, f.archive.file(export.ID, "role_events.jsonl"), "secções vazias geram ficheiros vazios")
+
+	reader, err := f.service.OpenFile(ctx, f.tenantID, export.ID, model.TenantExportManifestFileName)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	var manifest model.TenantExportManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	payload, err := manifest.SigningPayload()
+	require.NoError(t, err)
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(f.publicKey, payload, signature), "assinatura do manifesto")
+	assert.Equal(t, "tenant-export-test", manifest.KeyID)
+
+	signingKey := f.service.SigningKey()
+	assert.Equal(t, base64.StdEncoding.EncodeToString(f.publicKey), signingKey.PublicKey)
+
+	for _, file := range manifest.Files {
+		content := f.archive.file(export.ID, file.Name)
+		digest := sha256.Sum256([]byte(content))
+		assert.Equal(t, hex.EncodeToString(digest[:]), file.SHA256, file.Name)
+		assert.Equal(t, int64(len(content)), file.Bytes, file.Name)
+	}
+	usersFile, ok := manifest.File("users.jsonl")
+	require.True(t, ok)
+	assert.Equal(t, int64(5), usersFile.Records)
+}
+
+// TestTenantExportPIIPerMarket valida o tratamento dos dados pessoais exigido pelo mercado
+func TestTenantExportPIIPerMarket(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Tratamento inferior ao do mercado é recusado", func(t *testing.T) {
+		f := newTenantExportFixture(t, 10)
+		_, err := f.service.Start(ctx, &application.StartTenantExportRequest{
+			TenantID: f.tenantID, RequestedBy: f.operatorID,
+			Market: "angola", PIIMode: model.TenantExportPIIInclude,
+		})
+		assert.ErrorIs(t, err, application.ErrInvalidTenantExport)
+	})
+
+	t.Run("Angola oculta os dados pessoais em CSV", func(t *testing.T) {
+		f := newTenantExportFixture(t, 10)
+		export := f.start(t, "angola", model.TenantExportFormatCSV, "")
+		assert.Equal(t, model.TenantExportPIIMask, export.PIIMode)
+
+		_, err := f.service.Run(ctx, f.tenantID, export.ID)
+		require.NoError(t, err)
+
+		rows, err := csv.NewReader(strings.NewReader(f.archive.file(export.ID, "users.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 6)
+		assert.Equal(t, []string{"id", "username", "email"}, rows[0][:3])
+		assert.Equal(t, "u***", rows[1][1])
+		assert.Equal(t, "u***@banco.ao", rows[1][2])
+		assert.Equal(t, "A***", rows[1][4])
+		assert.Equal(t, "2025-03-10T09:30:00Z", rows[1][17])
+		assert.JSONEq(t, `{"department":"Tesouraria","manager":{"email":"c***@banco.ao"}}`, rows[1][14])
+
+		audit, err := csv.NewReader(strings.NewReader(f.archive.file(export.ID, "audit_logs.csv"))).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "10.***", audit[1][5])
+		assert.NotContains(t, f.archive.file(export.ID, "audit_logs.csv"), "utilizador0@banco.ao")
+	})
+
+	t.Run("UE pseudonimiza com pseudónimos estáveis no arquivo", func(t *testing.T) {
+		f := newTenantExportFixture(t, 10)
+		export := f.start(t, "EU", model.TenantExportFormatJSON, "")
+		assert.Equal(t, model.TenantExportPIIPseudonymize, export.PIIMode)
+
+		_, err := f.service.Run(ctx, f.tenantID, export.ID)
+		require.NoError(t, err)
+
+		users := jsonLines(t, f.archive.file(export.ID, "users.jsonl"))
+		email := users[0]["email"].(string)
+		assert.True(t, strings.HasPrefix(email, "pseud:"))
+		assert.Equal(t, users[0]["first_name"], users[1]["first_name"], "o mesmo valor tem o mesmo pseudónimo")
+		assert.NotEqual(t, users[0]["email"], users[1]["email"])
+
+		audit := jsonLines(t, f.archive.file(export.ID, "audit_logs.jsonl"))
+		newValue := audit[0]["new_value"].(map[string]interface{})
+		assert.Equal(t, email, newValue["email"], "pseudónimo consistente entre secções")
+		assert.Equal(t, "active", newValue["status"])
+	})
+}
+
+// TestTenantExportResumesWithoutDuplicates valida a retoma a partir do último lote confirmado
+func TestTenantExportResumesWithoutDuplicates(t *testing.T) {
+	f := newTenantExportFixture(t, 2)
+	ctx := context.Background()
+	export := f.start(t, "usa", model.TenantExportFormatCSV, "")
+
+	// O segundo lote de usuários é gravado a meio e falha
+	f.archive.failAfter = 1
+	f.archive.failAppends = 1
+
+	failed, err := f.service.Run(ctx, f.tenantID, export.ID)
+	require.Error(t, err)
+	assert.Equal(t, model.TenantExportStatusFailed, failed.Status)
+	assert.Contains(t, failed.Error, "disco indisponível")
+	assert.Equal(t, int64(2), failed.Progress[0].Records, "o primeiro lote continua confirmado")
+
+	_, err = f.service.OpenFile(ctx, f.tenantID, export.ID, "users.csv")
+	assert.ErrorIs(t, err, application.ErrTenantExportNotReady)
+
+	_, err = f.service.Run(ctx, f.tenantID, export.ID)
+	assert.ErrorIs(t, err, application.ErrInvalidTenantExport, "exportações falhadas exigem retoma explícita")
+
+	resumed, err := f.service.Resume(ctx, f.tenantID, export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TenantExportStatusPending, resumed.Status)
+	assert.Empty(t, resumed.Error)
+
+	result, err := f.service.RunPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Completed)
+
+	completed, err := f.service.Get(ctx, f.tenantID, export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TenantExportStatusCompleted, completed.Status)
+	assert.Equal(t, 2, completed.Attempts)
+
+	rows, err := csv.NewReader(strings.NewReader(f.archive.file(export.ID, "users.csv"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6, "cabeçalho e cinco usuários, sem linhas parciais ou repetidas")
+	for i, row := range rows[1:] {
+		assert.Equal(t, fmt.Sprintf("utilizador%d", i), row[1])
+	}
+
+	file, ok := completed.Manifest.File("users.csv")
+	require.True(t, ok)
+	assert.Equal(t, int64(5), file.Records)
+}
+
+// TestTenantExportSingleActivePerTenant valida a exclusividade e os acessos por tenant
+func TestTenantExportSingleActivePerTenant(t *testing.T) {
+	f := newTenantExportFixture(t, 10)
+	ctx := context.Background()
+	export := f.start(t, "usa", model.TenantExportFormatJSON, "")
+
+	_, err := f.service.Start(ctx, &application.StartTenantExportRequest{
+		TenantID: f.tenantID, RequestedBy: f.operatorID, Market: "usa",
+	})
+	assert.ErrorIs(t, err, application.ErrTenantExportInProgress)
+
+	_, err = f.service.Get(ctx, uuid.New(), export.ID)
+	assert.ErrorIs(t, err, application.ErrTenantExportNotFound)
+
+	_, err = f.service.Run(ctx, f.tenantID, export.ID)
+	require.NoError(t, err)
+
+	_, err = f.service.OpenFile(ctx, f.tenantID, export.ID, "../users.jsonl")
+	assert.ErrorIs(t, err, application.ErrTenantExportNotFound)
+
+	_, err = f.service.Resume(ctx, f.tenantID, export.ID)
+	assert.ErrorIs(t, err, application.ErrInvalidTenantExport, "exportações concluídas não são retomadas")
+
+	// Concluída a exportação, o tenant pode pedir outra
+	f.start(t, "usa", model.TenantExportFormatCSV, "")
+}
@@ -0,0 +1,604 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para a troca de tokens (TokenExchangeService, RFC 8693).
+ * Valida a redução dos escopos, a claim act, as políticas por audiência, a profundidade
+ * da delegação, a validade dos tokens emitidos e o registo de auditoria das trocas.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeTokenExchangeRepository é um TokenExchangeRepository em memória
+type fakeTokenExchangeRepository struct {
+	mu       sync.Mutex
+	policies map[uuid.UUID]*model.TokenExchangePolicy
+	events   []*model.TokenExchangeEvent
+	eventErr error
+}
+
+func newFakeTokenExchangeRepository() *fakeTokenExchangeRepository {
+	return &fakeTokenExchangeRepository{
+		policies: make(map[uuid.UUID]*model.TokenExchangePolicy),
+	}
+}
+
+func (r *fakeTokenExchangeRepository) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.TokenExchangePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var policies []*model.TokenExchangePolicy
+	for _, policy := range r.policies {
+		if policy.TenantID == tenantID {
+			copied := *policy
+			policies = append(policies, &copied)
+		}
+	}
+	return policies, nil
+}
+
+func (r *fakeTokenExchangeRepository) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.TokenExchangePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.policies[policyID]
+	if !ok || policy.TenantID != tenantID {
+		return nil, model.ErrTokenExchangePolicyNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+func (r *fakeTokenExchangeRepository) GetPolicyByAudience(ctx context.Context, tenantID uuid.UUID, audience string) (*model.TokenExchangePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, policy := range r.policies {
+		if policy.TenantID == tenantID && policy.Audience == audience {
+			copied := *policy
+			return &copied, nil
+		}
+	}
+	return nil, model.ErrTokenExchangePolicyNotFound
+}
+
+func (r *fakeTokenExchangeRepository) CreatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.policies {
+		if existing.TenantID == policy.TenantID && existing.Audience == policy.Audience {
+			return model.ErrTokenExchangePolicyConflict
+		}
+	}
+	copied := *policy
+	r.policies[policy.ID] = &copied
+	return nil
+}
+
+func (r *fakeTokenExchangeRepository) UpdatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.policies[policy.ID]; !ok || existing.TenantID != policy.TenantID {
+		return model.ErrTokenExchangePolicyNotFound
+	}
+	copied := *policy
+	r.policies[policy.ID] = &copied
+	return nil
+}
+
+func (r *fakeTokenExchangeRepository) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.policies[policyID]; !ok || existing.TenantID != tenantID {
+		return model.ErrTokenExchangePolicyNotFound
+	}
+	delete(r.policies, policyID)
+	return nil
+}
+
+func (r *fakeTokenExchangeRepository) SaveEvent(ctx context.Context, event *model.TokenExchangeEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.eventErr != nil {
+		return r.eventErr
+	}
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+func (r *fakeTokenExchangeRepository) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.TokenExchangeEventFilter) ([]*model.TokenExchangeEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []*model.TokenExchangeEvent
+	for i := len(r.events) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		event := r.events[i]
+		if event.TenantID != tenantID || (filter.Outcome != "" && event.Outcome != filter.Outcome) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (r *fakeTokenExchangeRepository) recorded() []*model.TokenExchangeEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.TokenExchangeEvent{}, r.events...)
+}
+
+// fakeTokenExchangeCodec associa tokens opacos às suas claims, sem assinatura real
+type fakeTokenExchangeCodec struct {
+	mu     sync.Mutex
+	tokens map[string]*model.TokenClaims
+}
+
+func newFakeTokenExchangeCodec() *fakeTokenExchangeCodec {
+	return &fakeTokenExchangeCodec{tokens: make(map[string]*model.TokenClaims)}
+}
+
+func (c *fakeTokenExchangeCodec) Parse(token string) (*model.TokenClaims, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	claims, ok := c.tokens[token]
+	if !ok || !claims.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("token inválido")
+	}
+	copied := *claims
+	return &copied, nil
+}
+
+func (c *fakeTokenExchangeCodec) Sign(claims *model.TokenClaims) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token := fmt.Sprintf("delegated-%d", len(c.tokens))
+	copied := *claims
+	c.tokens[token] = &copied
+	return token, nil
+}
+
+func (c *fakeTokenExchangeCodec) issue(token string, claims *model.TokenClaims) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[token] = claims
+	return token
+}
+
+func (c *fakeTokenExchangeCodec) claims(token string) *model.TokenClaims {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[token]
+}
+
+type tokenExchangeFixture struct {
+	service  application.TokenExchangeService
+	repo     *fakeTokenExchangeRepository
+	codec    *fakeTokenExchangeCodec
+	tenantID uuid.UUID
+	userID   uuid.UUID
+}
+
+func newTokenExchangeFixture(t *testing.T) *tokenExchangeFixture {
+	repo := newFakeTokenExchangeRepository()
+	codec := newFakeTokenExchangeCodec()
+	fixture := &tokenExchangeFixture{
+		service:  impl.NewTokenExchangeService(repo, codec, impl.TokenExchangeConfig{Issuer: "innovabiz-iam", MaxTTL: 10 * time.Minute}),
+		repo:     repo,
+		codec:    codec,
+		tenantID: uuid.New(),
+		userID:   uuid.New(),
+	}
+
+	_, err := fixture.service.CreatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      fixture.tenantID,
+		Audience:      "credit-bureau",
+		AllowedActors: []string{"payment-gateway", "risk-engine"},
+		AllowedScopes: []string{"bureau:read", "bureau:score", "bureau:write"},
+		MaxTTLSeconds: 300,
+		Enabled:       true,
+		ActorID:       uuid.New(),
+	})
+	require.NoError(t, err)
+	return fixture
+}
+
+// userToken emite um token de sessão do usuário, sem escopos delimitados
+func (f *tokenExchangeFixture) userToken(ttl time.Duration) string {
+	return f.codec.issue("user-"+uuid.NewString(), &model.TokenClaims{
+		Subject:   f.userID.String(),
+		TenantID:  f.tenantID.String(),
+		Audience:  []string{"innovabiz-iam"},
+		Username:  "ana.silva",
+		Market:    "AO",
+		ExpiresAt: time.Now().Add(ttl),
+	})
+}
+
+// serviceToken emite o token de um serviço do tenant
+func (f *tokenExchangeFixture) serviceToken(clientID string, tenantID uuid.UUID) string {
+	return f.codec.issue("service-"+clientID+"-"+uuid.NewString(), &model.TokenClaims{
+		Subject:   "svc-" + clientID,
+		TenantID:  tenantID.String(),
+		ClientID:  clientID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+}
+
+func (f *tokenExchangeFixture) request(subjectToken, actorToken, audience string, scopes ...string) *application.TokenExchangeRequest {
+	return &application.TokenExchangeRequest{
+		GrantType:        model.GrantTypeTokenExchange,
+		SubjectToken:     subjectToken,
+		SubjectTokenType: model.TokenTypeAccessToken,
+		ActorToken:       actorToken,
+		ActorTokenType:   model.TokenTypeAccessToken,
+		Audience:         audience,
+		Scopes:           scopes,
+		IPAddress:        "10.0.0.7",
+	}
+}
+
+func TestTokenExchangeIssuesDownscopedDelegatedToken(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+
+	resp, err := f.service.Exchange(context.Background(),
+		f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau", "bureau:score", "bureau:read"))
+	require.NoError(t, err)
+
+	assert.Equal(t, model.TokenTypeAccessToken, resp.IssuedTokenType)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, 300, resp.ExpiresIn)
+	assert.Equal(t, "bureau:read bureau:score", resp.Scope)
+
+	claims := f.codec.claims(resp.AccessToken)
+	require.NotNil(t, claims)
+	assert.Equal(t, f.userID.String(), claims.Subject)
+	assert.Equal(t, f.tenantID.String(), claims.TenantID)
+	assert.Equal(t, "innovabiz-iam", claims.Issuer)
+	assert.Equal(t, []string{"credit-bureau"}, claims.Audience)
+	assert.Equal(t, []string{"bureau:read", "bureau:score"}, claims.Scopes)
+	assert.Equal(t, "payment-gateway", claims.ClientID)
+	assert.Equal(t, "ana.silva", claims.Username)
+	require.NotNil(t, claims.Actor)
+	assert.Equal(t, "payment-gateway", claims.Actor.ClientID)
+	assert.Nil(t, claims.Actor.Actor)
+
+	events := f.repo.recorded()
+	require.Len(t, events, 1)
+	assert.Equal(t, model.TokenExchangeIssued, events[0].Outcome)
+	assert.Equal(t, "payment-gateway", events[0].Actor)
+	assert.Equal(t, claims.TokenID, events[0].TokenID)
+	assert.Equal(t, "10.0.0.7", events[0].IPAddress)
+	require.NotNil(t, events[0].PolicyID)
+}
+
+func TestTokenExchangeGrantsAllPolicyScopesWhenNoneRequested(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+
+	resp, err := f.service.Exchange(context.Background(),
+		f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+	require.NoError(t, err)
+	assert.Equal(t, "bureau:read bureau:score bureau:write", resp.Scope)
+}
+
+func TestTokenExchangeCapsTTLAtSubjectTokenExpiry(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+
+	resp, err := f.service.Exchange(context.Background(),
+		f.request(f.userToken(90*time.Second), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, resp.ExpiresIn, 90)
+	assert.Greater(t, resp.ExpiresIn, 80)
+}
+
+func TestTokenExchangeDenials(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(f *tokenExchangeFixture) *application.TokenExchangeRequest
+		err     error
+		reason  string
+	}{
+		{
+			name: "serviço fora da política",
+			prepare: func(f *tokenExchangeFixture) *application.TokenExchangeRequest {
+				return f.request(f.userToken(time.Hour), f.serviceToken("marketing", f.tenantID), "credit-bureau")
+			},
+			err:    model.ErrTokenExchangeActorNotAllowed,
+			reason: model.TokenExchangeErrorUnauthorizedClient,
+		},
+		{
+			name: "escopo fora da política",
+			prepare: func(f *tokenExchangeFixture) *application.TokenExchangeRequest {
+				return f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau", "bureau:admin")
+			},
+			err:    model.ErrTokenExchangeScopeNotAllowed,
+			reason: model.TokenExchangeErrorInvalidScope,
+		},
+		{
+			name: "audiência sem política",
+			prepare: func(f *tokenExchangeFixture) *application.TokenExchangeRequest {
+				return f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "ledger")
+			},
+			err:    model.ErrTokenExchangeTargetNotAllowed,
+			reason: model.TokenExchangeErrorInvalidTarget,
+		},
+		{
+			name: "token do serviço de outro tenant",
+			prepare: func(f *tokenExchangeFixture) *application.TokenExchangeRequest {
+				return f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", uuid.New()), "credit-bureau")
+			},
+			err:    model.ErrInvalidActorToken,
+			reason: model.TokenExchangeErrorInvalidGrant,
+		},
+		{
+			name: "token do serviço desconhecido",
+			prepare: func(f *tokenExchangeFixture) *application.TokenExchangeRequest {
+				return f.request(f.userToken(time.Hour), "forged", "credit-bureau")
+			},
+			err:    model.ErrInvalidActorToken,
+			reason: model.TokenExchangeErrorInvalidGrant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTokenExchangeFixture(t)
+
+			_, err := f.service.Exchange(context.Background(), tt.prepare(f))
+			require.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.reason, model.TokenExchangeErrorCode(err))
+
+			events := f.repo.recorded()
+			require.Len(t, events, 1)
+			assert.Equal(t, model.TokenExchangeDenied, events[0].Outcome)
+			assert.Equal(t, tt.reason, events[0].Reason)
+			assert.Empty(t, events[0].TokenID)
+		})
+	}
+}
+
+func TestTokenExchangeDisabledPolicyDeniesTarget(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	policies, err := f.service.ListPolicies(context.Background(), f.tenantID)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+
+	policy := policies[0]
+	_, err = f.service.UpdatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      f.tenantID,
+		PolicyID:      policy.ID,
+		Audience:      policy.Audience,
+		AllowedActors: policy.AllowedActors,
+		AllowedScopes: policy.AllowedScopes,
+		MaxTTLSeconds: policy.MaxTTLSeconds,
+		Enabled:       false,
+		ActorID:       uuid.New(),
+	})
+	require.NoError(t, err)
+
+	_, err = f.service.Exchange(context.Background(),
+		f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+	assert.ErrorIs(t, err, model.ErrTokenExchangeTargetNotAllowed)
+}
+
+func TestTokenExchangeChainedDelegation(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	_, err := f.service.CreatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      f.tenantID,
+		Audience:      "risk-engine",
+		AllowedActors: []string{"payment-gateway"},
+		AllowedScopes: []string{"risk:score"},
+		MaxTTLSeconds: 300,
+		Enabled:       true,
+		ActorID:       uuid.New(),
+	})
+	require.NoError(t, err)
+
+	// O gateway obtém um token para o motor de risco, que o troca por um token para o bureau
+	first, err := f.service.Exchange(context.Background(),
+		f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "risk-engine"))
+	require.NoError(t, err)
+
+	t.Run("profundidade excedida", func(t *testing.T) {
+		_, err := f.service.Exchange(context.Background(),
+			f.request(first.AccessToken, f.serviceToken("risk-engine", f.tenantID), "credit-bureau"))
+		assert.ErrorIs(t, err, model.ErrDelegationDepthExceeded)
+	})
+
+	t.Run("token delegado para outro serviço", func(t *testing.T) {
+		_, err := f.service.Exchange(context.Background(),
+			f.request(first.AccessToken, f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+		assert.ErrorIs(t, err, model.ErrInvalidSubjectToken)
+	})
+
+	t.Run("cadeia permitida", func(t *testing.T) {
+		policies, err := f.service.ListPolicies(context.Background(), f.tenantID)
+		require.NoError(t, err)
+		for _, policy := range policies {
+			if policy.Audience != "credit-bureau" {
+				continue
+			}
+			_, err = f.service.UpdatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+				TenantID:           f.tenantID,
+				PolicyID:           policy.ID,
+				Audience:           policy.Audience,
+				AllowedActors:      policy.AllowedActors,
+				AllowedScopes:      policy.AllowedScopes,
+				MaxTTLSeconds:      policy.MaxTTLSeconds,
+				MaxDelegationDepth: 2,
+				Enabled:            true,
+				ActorID:            uuid.New(),
+			})
+			require.NoError(t, err)
+		}
+
+		// O token do titular delimita escopos, que já não incluem os do bureau
+		_, err = f.service.Exchange(context.Background(),
+			f.request(first.AccessToken, f.serviceToken("risk-engine", f.tenantID), "credit-bureau"))
+		require.ErrorIs(t, err, model.ErrTokenExchangeScopeNotAllowed)
+
+		f.codec.claims(first.AccessToken).Scopes = []string{"risk:score", "bureau:score"}
+		resp, err := f.service.Exchange(context.Background(),
+			f.request(first.AccessToken, f.serviceToken("risk-engine", f.tenantID), "credit-bureau"))
+		require.NoError(t, err)
+		assert.Equal(t, "bureau:score", resp.Scope)
+
+		claims := f.codec.claims(resp.AccessToken)
+		require.NotNil(t, claims.Actor)
+		assert.Equal(t, []string{"risk-engine", "payment-gateway"}, claims.Actor.Chain())
+	})
+}
+
+// fakeClaimsResolver retorna as claims personalizadas atuais dos usuários do tenant
+type fakeClaimsResolver struct {
+	claims map[uuid.UUID]map[string]interface{}
+}
+
+func (r *fakeClaimsResolver) ResolveClaims(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error) {
+	claims, ok := r.claims[userID]
+	if !ok {
+		return nil, model.ErrUserNotFound
+	}
+	return claims, nil
+}
+
+func TestTokenExchangeIssuesCurrentCustomClaims(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	resolver := &fakeClaimsResolver{claims: map[uuid.UUID]map[string]interface{}{
+		f.userID: {"https://innovabiz.com/clearance": "internal"},
+	}}
+	service := impl.NewTokenExchangeService(f.repo, f.codec, impl.TokenExchangeConfig{
+		Issuer: "innovabiz-iam", MaxTTL: 10 * time.Minute, ClaimsResolver: resolver,
+	})
+
+	// As claims do token do titular são substituídas pelas atuais do usuário
+	subject := f.userToken(time.Hour)
+	f.codec.claims(subject).CustomClaims = map[string]interface{}{"https://innovabiz.com/clearance": "secret"}
+	resp, err := service.Exchange(context.Background(),
+		f.request(subject, f.serviceToken("payment-gateway", f.tenantID), "credit-bureau", "bureau:score"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"https://innovabiz.com/clearance": "internal"}, f.codec.claims(resp.AccessToken).CustomClaims)
+
+	// Os titulares que não são usuários do tenant mantêm as claims do seu token
+	delete(resolver.claims, f.userID)
+	resp, err = service.Exchange(context.Background(),
+		f.request(subject, f.serviceToken("payment-gateway", f.tenantID), "credit-bureau", "bureau:score"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"https://innovabiz.com/clearance": "secret"}, f.codec.claims(resp.AccessToken).CustomClaims)
+}
+
+func TestTokenExchangeRejectsInvalidRequests(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	subject := f.userToken(time.Hour)
+	actor := f.serviceToken("payment-gateway", f.tenantID)
+
+	req := f.request(subject, actor, "credit-bureau")
+	req.GrantType = "client_credentials"
+	_, err := f.service.Exchange(context.Background(), req)
+	assert.ErrorIs(t, err, model.ErrInvalidTokenExchangeRequest)
+
+	req = f.request(subject, "", "credit-bureau")
+	_, err = f.service.Exchange(context.Background(), req)
+	assert.ErrorIs(t, err, model.ErrInvalidTokenExchangeRequest)
+
+	req = f.request(subject, actor, "")
+	req.Resource = "credit-bureau"
+	_, err = f.service.Exchange(context.Background(), req)
+	assert.NoError(t, err)
+
+	_, err = f.service.Exchange(context.Background(), f.request("unknown", actor, "credit-bureau"))
+	assert.ErrorIs(t, err, model.ErrInvalidSubjectToken)
+}
+
+func TestTokenExchangeFailsWhenAuditFails(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	f.repo.eventErr = errors.New("database unavailable")
+
+	resp, err := f.service.Exchange(context.Background(),
+		f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestTokenExchangePolicyValidation(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+
+	_, err := f.service.CreatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      f.tenantID,
+		Audience:      "credit-bureau",
+		AllowedActors: []string{"payment-gateway"},
+		AllowedScopes: []string{"bureau:read"},
+		MaxTTLSeconds: 300,
+		ActorID:       uuid.New(),
+	})
+	assert.ErrorIs(t, err, model.ErrTokenExchangePolicyConflict)
+
+	_, err = f.service.CreatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      f.tenantID,
+		Audience:      "ledger",
+		AllowedActors: []string{"payment-gateway"},
+		AllowedScopes: []string{"ledger:read"},
+		MaxTTLSeconds: 7200,
+		ActorID:       uuid.New(),
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidTokenExchangePolicy)
+
+	_, err = f.service.CreatePolicy(context.Background(), &application.SaveTokenExchangePolicyRequest{
+		TenantID:      f.tenantID,
+		Audience:      "ledger",
+		AllowedScopes: []string{"ledger:read"},
+		MaxTTLSeconds: 300,
+		ActorID:       uuid.New(),
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidTokenExchangePolicy)
+}
+
+func TestTokenExchangeListEvents(t *testing.T) {
+	f := newTokenExchangeFixture(t)
+	ctx := context.Background()
+
+	_, err := f.service.Exchange(ctx, f.request(f.userToken(time.Hour), f.serviceToken("payment-gateway", f.tenantID), "credit-bureau"))
+	require.NoError(t, err)
+	_, err = f.service.Exchange(ctx, f.request(f.userToken(time.Hour), f.serviceToken("marketing", f.tenantID), "credit-bureau"))
+	require.Error(t, err)
+
+	denied, err := f.service.ListEvents(ctx, f.tenantID, model.TokenExchangeEventFilter{Outcome: model.TokenExchangeDenied})
+	require.NoError(t, err)
+	require.Len(t, denied, 1)
+	assert.Equal(t, "marketing", denied[0].Actor)
+
+	all, err := f.service.ListEvents(ctx, f.tenantID, model.TokenExchangeEventFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	other, err := f.service.ListEvents(ctx, uuid.New(), model.TokenExchangeEventFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	_, err = f.service.ListEvents(ctx, f.tenantID, model.TokenExchangeEventFilter{Outcome: "pending"})
+	assert.ErrorIs(t, err, model.ErrInvalidTokenExchangeFilter)
+}
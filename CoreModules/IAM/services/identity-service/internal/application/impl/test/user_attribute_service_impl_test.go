@@ -0,0 +1,370 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para os atributos personalizados dos usuários (UserAttributeService).
+ * Valida os esquemas tipados, a validação dos atributos nas escritas, os valores expostos
+ * às políticas ABAC, as claims dos tokens e o cache dos esquemas por tenant.
+ */
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeUserAttributeRepository é um UserAttributeRepository em memória
+type fakeUserAttributeRepository struct {
+	mu          sync.Mutex
+	schemas     map[uuid.UUID]*model.UserAttributeSchema
+	attributes  map[uuid.UUID]*model.UserAttributes
+	users       map[uuid.UUID]uuid.UUID
+	listCalls   int
+	lookupCalls int
+}
+
+func newFakeUserAttributeRepository() *fakeUserAttributeRepository {
+	return &fakeUserAttributeRepository{
+		schemas:    make(map[uuid.UUID]*model.UserAttributeSchema),
+		attributes: make(map[uuid.UUID]*model.UserAttributes),
+		users:      make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func (r *fakeUserAttributeRepository) ListSchemas(ctx context.Context, tenantID uuid.UUID) ([]*model.UserAttributeSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listCalls++
+	var schemas []*model.UserAttributeSchema
+	for _, schema := range r.schemas {
+		if schema.TenantID == tenantID {
+			copied := *schema
+			schemas = append(schemas, &copied)
+		}
+	}
+	return schemas, nil
+}
+
+func (r *fakeUserAttributeRepository) GetSchema(ctx context.Context, tenantID, schemaID uuid.UUID) (*model.UserAttributeSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schema, ok := r.schemas[schemaID]
+	if !ok || schema.TenantID != tenantID {
+		return nil, model.ErrUserAttributeSchemaNotFound
+	}
+	copied := *schema
+	return &copied, nil
+}
+
+func (r *fakeUserAttributeRepository) CreateSchema(ctx context.Context, schema *model.UserAttributeSchema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conflicts(schema) {
+		return model.ErrUserAttributeSchemaConflict
+	}
+	copied := *schema
+	r.schemas[schema.ID] = &copied
+	return nil
+}
+
+func (r *fakeUserAttributeRepository) UpdateSchema(ctx context.Context, schema *model.UserAttributeSchema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.schemas[schema.ID]; !ok {
+		return model.ErrUserAttributeSchemaNotFound
+	}
+	if r.conflicts(schema) {
+		return model.ErrUserAttributeSchemaConflict
+	}
+	copied := *schema
+	r.schemas[schema.ID] = &copied
+	return nil
+}
+
+// conflicts simula as restrições de unicidade do nome e da claim por tenant
+func (r *fakeUserAttributeRepository) conflicts(schema *model.UserAttributeSchema) bool {
+	for _, existing := range r.schemas {
+		if existing.ID == schema.ID || existing.TenantID != schema.TenantID {
+			continue
+		}
+		if existing.Name == schema.Name || (schema.ClaimName != "" && existing.ClaimName == schema.ClaimName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeUserAttributeRepository) DeleteSchema(ctx context.Context, tenantID, schemaID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schema, ok := r.schemas[schemaID]
+	if !ok || schema.TenantID != tenantID {
+		return model.ErrUserAttributeSchemaNotFound
+	}
+	delete(r.schemas, schemaID)
+	for _, attributes := range r.attributes {
+		if attributes.TenantID == tenantID {
+			delete(attributes.Values, schema.Name)
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserAttributeRepository) GetUserAttributes(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lookupCalls++
+	if r.users[userID] != tenantID {
+		return nil, model.ErrUserNotFound
+	}
+	attributes, ok := r.attributes[userID]
+	if !ok {
+		return &model.UserAttributes{TenantID: tenantID, UserID: userID}, nil
+	}
+	copied := *attributes
+	copied.Values = make(map[string]interface{}, len(attributes.Values))
+	for name, value := range attributes.Values {
+		copied.Values[name] = value
+	}
+	return &copied, nil
+}
+
+func (r *fakeUserAttributeRepository) SaveUserAttributes(ctx context.Context, attributes *model.UserAttributes) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users[attributes.UserID] != attributes.TenantID {
+		return model.ErrUserNotFound
+	}
+	copied := *attributes
+	r.attributes[attributes.UserID] = &copied
+	return nil
+}
+
+type userAttributeFixture struct {
+	service  application.UserAttributeService
+	repo     *fakeUserAttributeRepository
+	tenantID uuid.UUID
+	userID   uuid.UUID
+	actorID  uuid.UUID
+}
+
+func newUserAttributeFixture(t *testing.T) *userAttributeFixture {
+	repo := newFakeUserAttributeRepository()
+	fixture := &userAttributeFixture{
+		service:  impl.NewUserAttributeService(repo, time.Hour),
+		repo:     repo,
+		tenantID: uuid.New(),
+		userID:   uuid.New(),
+		actorID:  uuid.New(),
+	}
+	repo.users[fixture.userID] = fixture.tenantID
+	return fixture
+}
+
+func (f *userAttributeFixture) createSchema(t *testing.T, req application.SaveUserAttributeSchemaRequest) *model.UserAttributeSchema {
+	req.TenantID = f.tenantID
+	req.ActorID = f.actorID
+	schema, err := f.service.CreateSchema(context.Background(), &req)
+	require.NoError(t, err)
+	return schema
+}
+
+func (f *userAttributeFixture) setAttributes(values map[string]interface{}) (*model.UserAttributes, error) {
+	return f.service.SetUserAttributes(context.Background(), &application.SetUserAttributesRequest{
+		TenantID: f.tenantID,
+		UserID:   f.userID,
+		Values:   values,
+		ActorID:  f.actorID,
+	})
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestUserAttributesValidatedAgainstTenantSchemas(t *testing.T) {
+	f := newUserAttributeFixture(t)
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "cost_center", Type: model.UserAttributeString, Required: true, Pattern: `^CC-[0-9]{3}$`,
+	})
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "clearance_level", Type: model.UserAttributeEnum, EnumValues: []string{"secret", "internal", "public"},
+	})
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "approval_limit", Type: model.UserAttributeInteger, MinValue: floatPtr(0), MaxValue: floatPtr(1000000),
+	})
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "contract_end", Type: model.UserAttributeDate,
+	})
+
+	attributes, err := f.setAttributes(map[string]interface{}{
+		"cost_center":     "CC-100",
+		"clearance_level": "secret",
+		"approval_limit":  json.Number("250000"),
+		"contract_end":    "2027-03-31",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"cost_center":     "CC-100",
+		"clearance_level": "secret",
+		"approval_limit":  int64(250000),
+		"contract_end":    "2027-03-31",
+	}, attributes.Values)
+	require.NotNil(t, attributes.UpdatedBy)
+	assert.Equal(t, f.actorID, *attributes.UpdatedBy)
+
+	invalid := []map[string]interface{}{
+		{"clearance_level": "secret"},
+		{"cost_center": "100"},
+		{"cost_center": "CC-100", "clearance_level": "top-secret"},
+		{"cost_center": "CC-100", "approval_limit": 1.5},
+		{"cost_center": "CC-100", "approval_limit": 2000000},
+		{"cost_center": "CC-100", "contract_end": "31/03/2027"},
+		{"cost_center": "CC-100", "department": "finance"},
+	}
+	for _, values := range invalid {
+		_, err := f.setAttributes(values)
+		assert.ErrorIs(t, err, application.ErrInvalidUserAttributes, "%v", values)
+	}
+
+	stored, err := f.service.GetUserAttributes(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, "CC-100", stored.Values["cost_center"])
+	assert.Len(t, stored.Values, 4)
+
+	_, err = f.service.SetUserAttributes(context.Background(), &application.SetUserAttributesRequest{
+		TenantID: f.tenantID, UserID: uuid.New(), Values: map[string]interface{}{"cost_center": "CC-100"},
+	})
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
+func TestUserAttributeSchemaValidation(t *testing.T) {
+	f := newUserAttributeFixture(t)
+
+	invalid := []application.SaveUserAttributeSchemaRequest{
+		{Name: "Cost Center", Type: model.UserAttributeString},
+		{Name: "cost_center", Type: "currency"},
+		{Name: "clearance_level", Type: model.UserAttributeEnum},
+		{Name: "cost_center", Type: model.UserAttributeString, Pattern: "("},
+		{Name: "cost_center", Type: model.UserAttributeString, MinValue: floatPtr(1)},
+		{Name: "approval_limit", Type: model.UserAttributeInteger, MinValue: floatPtr(10), MaxValue: floatPtr(1)},
+		{Name: "cost_center", Type: model.UserAttributeString, ClaimName: "sub"},
+		{Name: "cost_center", Type: model.UserAttributeString, ClaimName: "email"},
+	}
+	for _, req := range invalid {
+		req.TenantID = f.tenantID
+		_, err := f.service.CreateSchema(context.Background(), &req)
+		assert.ErrorIs(t, err, application.ErrInvalidUserAttributeSchema, "%+v", req)
+	}
+
+	schema := f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "cost_center", Type: model.UserAttributeString, ClaimName: "cost_center",
+	})
+	_, err := f.service.CreateSchema(context.Background(), &application.SaveUserAttributeSchemaRequest{
+		TenantID: f.tenantID, Name: "department", Type: model.UserAttributeString, ClaimName: "cost_center",
+	})
+	assert.ErrorIs(t, err, application.ErrUserAttributeSchemaConflict)
+
+	// O nome e o tipo não podem ser alterados
+	_, err = f.service.UpdateSchema(context.Background(), &application.SaveUserAttributeSchemaRequest{
+		TenantID: f.tenantID, SchemaID: schema.ID, Name: "cost_center", Type: model.UserAttributeInteger,
+	})
+	assert.ErrorIs(t, err, application.ErrInvalidUserAttributeSchema)
+
+	_, err = f.service.GetSchema(context.Background(), f.tenantID, uuid.New())
+	assert.ErrorIs(t, err, application.ErrUserAttributeSchemaNotFound)
+	_, err = f.service.GetSchema(context.Background(), uuid.New(), schema.ID)
+	assert.ErrorIs(t, err, application.ErrUserAttributeSchemaNotFound)
+}
+
+func TestUserAttributeSchemaLimit(t *testing.T) {
+	f := newUserAttributeFixture(t)
+	for i := 0; i < model.MaxUserAttributeSchemas; i++ {
+		f.repo.schemas[uuid.New()] = &model.UserAttributeSchema{TenantID: f.tenantID, Name: "attribute_" + uuid.NewString()[:8]}
+	}
+
+	_, err := f.service.CreateSchema(context.Background(), &application.SaveUserAttributeSchemaRequest{
+		TenantID: f.tenantID, Name: "cost_center", Type: model.UserAttributeString,
+	})
+	assert.ErrorIs(t, err, application.ErrUserAttributeSchemaLimit)
+}
+
+func TestUserAttributesResolvedForPoliciesAndClaims(t *testing.T) {
+	f := newUserAttributeFixture(t)
+	clearance := f.createSchema(t, application.SaveUserAttributeSchemaRequest{
+		Name: "clearance_level", Type: model.UserAttributeEnum, EnumValues: []string{"secret", "internal"},
+		ClaimName: "https://innovabiz.com/clearance",
+	})
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{Name: "cost_center", Type: model.UserAttributeString})
+
+	_, err := f.setAttributes(map[string]interface{}{"clearance_level": "secret", "cost_center": "CC-100"})
+	require.NoError(t, err)
+
+	attributes, err := f.service.ResolveAttributes(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"clearance_level": "secret", "cost_center": "CC-100"}, attributes)
+
+	claims, err := f.service.ResolveClaims(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"https://innovabiz.com/clearance": "secret"}, claims)
+
+	// Um valor que deixa de ser permitido sai das decisões e dos tokens até ser corrigido
+	_, err = f.service.UpdateSchema(context.Background(), &application.SaveUserAttributeSchemaRequest{
+		TenantID: f.tenantID, SchemaID: clearance.ID, Name: "clearance_level", Type: model.UserAttributeEnum,
+		EnumValues: []string{"internal", "public"}, ClaimName: "https://innovabiz.com/clearance", ActorID: f.actorID,
+	})
+	require.NoError(t, err)
+
+	attributes, err = f.service.ResolveAttributes(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cost_center": "CC-100"}, attributes)
+	claims, err = f.service.ResolveClaims(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Empty(t, claims)
+
+	// A remoção do esquema retira o atributo de todos os usuários
+	require.NoError(t, f.service.DeleteSchema(context.Background(), f.tenantID, clearance.ID, f.actorID))
+	stored, err := f.service.GetUserAttributes(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cost_center": "CC-100"}, stored.Values)
+
+	_, err = f.service.ResolveAttributes(context.Background(), f.tenantID, uuid.New())
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+}
+
+func TestUserAttributeSchemasCachedPerTenant(t *testing.T) {
+	f := newUserAttributeFixture(t)
+
+	// Sem esquemas no tenant, os atributos do usuário não são consultados
+	attributes, err := f.service.ResolveAttributes(context.Background(), f.tenantID, f.userID)
+	require.NoError(t, err)
+	assert.Empty(t, attributes)
+	assert.Equal(t, 0, f.repo.lookupCalls)
+
+	f.createSchema(t, application.SaveUserAttributeSchemaRequest{Name: "cost_center", Type: model.UserAttributeString})
+	listCalls := f.repo.listCalls
+	for i := 0; i < 3; i++ {
+		_, err := f.service.ResolveAttributes(context.Background(), f.tenantID, f.userID)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, listCalls+1, f.repo.listCalls, "os esquemas são carregados uma vez e depois servidos do cache")
+}
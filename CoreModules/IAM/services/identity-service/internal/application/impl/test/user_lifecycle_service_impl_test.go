@@ -0,0 +1,379 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o serviço de ciclo de vida de usuários (UserLifecycleService).
+ * Valida as transições guardadas, a suspensão por inatividade, o desprovisionamento
+ * agendado e a publicação dos eventos de ciclo de vida.
+ */
+
+package test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/application"
+	"github.com/innovabiz/iam/internal/application/impl"
+	"github.com/innovabiz/iam/internal/domain/event"
+	"github.com/innovabiz/iam/internal/domain/model"
+)
+
+// fakeUserLifecycleRepository é um UserLifecycleRepository em memória
+type fakeUserLifecycleRepository struct {
+	mu          sync.Mutex
+	users       map[uuid.UUID]*model.User
+	transitions []*model.UserLifecycleTransition
+	policies    map[uuid.UUID]*model.UserLifecyclePolicy
+}
+
+func newFakeUserLifecycleRepository() *fakeUserLifecycleRepository {
+	return &fakeUserLifecycleRepository{
+		users:    make(map[uuid.UUID]*model.User),
+		policies: make(map[uuid.UUID]*model.UserLifecyclePolicy),
+	}
+}
+
+func (r *fakeUserLifecycleRepository) add(user *model.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *user
+	r.users[user.ID] = &copied
+}
+
+func (r *fakeUserLifecycleRepository) status(userID uuid.UUID) model.UserStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.users[userID].Status
+}
+
+func (r *fakeUserLifecycleRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, model.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserLifecycleRepository) SaveTransition(ctx context.Context, user *model.User, transition *model.UserLifecycleTransition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users[user.ID].Status != transition.FromStatus {
+		return model.ErrUserLifecycleConflict
+	}
+	copied := *user
+	r.users[user.ID] = &copied
+	r.transitions = append(r.transitions, transition)
+	return nil
+}
+
+func (r *fakeUserLifecycleRepository) ListTransitions(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.UserLifecycleTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var transitions []*model.UserLifecycleTransition
+	for _, transition := range r.transitions {
+		if transition.TenantID == tenantID && transition.UserID == userID {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+func (r *fakeUserLifecycleRepository) FindInactiveUsers(ctx context.Context, tenantID uuid.UUID, inactiveSince time.Time, limit int) ([]*model.User, error) {
+	return r.find(tenantID, limit, func(user *model.User) bool {
+		return user.Status == model.UserStatusActive && user.LastActivityAt().Before(inactiveSince)
+	})
+}
+
+func (r *fakeUserLifecycleRepository) FindSuspendedUsers(ctx context.Context, tenantID uuid.UUID, suspendedBefore time.Time, limit int) ([]*model.User, error) {
+	return r.find(tenantID, limit, func(user *model.User) bool {
+		return user.Status == model.UserStatusSuspended && user.StatusChangedAt.Before(suspendedBefore)
+	})
+}
+
+func (r *fakeUserLifecycleRepository) find(tenantID uuid.UUID, limit int, match func(*model.User) bool) ([]*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var users []*model.User
+	for _, user := range r.users {
+		if user.TenantID == tenantID && match(user) {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func (r *fakeUserLifecycleRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.UserLifecyclePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policy, ok := r.policies[tenantID]
+	if !ok {
+		return nil, model.ErrUserLifecyclePolicyNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+func (r *fakeUserLifecycleRepository) SavePolicy(ctx context.Context, policy *model.UserLifecyclePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *policy
+	r.policies[policy.TenantID] = &copied
+	return nil
+}
+
+func (r *fakeUserLifecycleRepository) ListPolicies(ctx context.Context) ([]*model.UserLifecyclePolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var policies []*model.UserLifecyclePolicy
+	for _, policy := range r.policies {
+		copied := *policy
+		policies = append(policies, &copied)
+	}
+	return policies, nil
+}
+
+// recordingPublisher guarda os eventos publicados
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, evt event.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, evt)
+	return nil
+}
+
+func (p *recordingPublisher) topics() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	topics := make([]string, 0, len(p.events))
+	for _, evt := range p.events {
+		topics = append(topics, evt.GetType())
+	}
+	return topics
+}
+
+// published retorna uma cópia dos eventos publicados
+func (p *recordingPublisher) published() []event.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]event.Event(nil), p.events...)
+}
+
+// newLifecycleUser cria um usuário no estado indicado com a última atividade no instante dado
+func newLifecycleUser(t *testing.T, tenantID uuid.UUID, username string, status model.UserStatus, lastActivity time.Time) *model.User {
+	user, err := model.NewUser(tenantID, username, username+"@innovabiz.com", "Teste", username)
+	require.NoError(t, err)
+
+	user.Status = status
+	user.CreatedAt = lastActivity
+	user.StatusChangedAt = &lastActivity
+	return user
+}
+
+func TestUserLifecycleService_InviteAndActivate(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeUserLifecycleRepository()
+	publisher := &recordingPublisher{}
+	service := impl.NewUserLifecycleService(repo, publisher, model.UserLifecyclePolicy{})
+
+	tenantID := uuid.New()
+	actorID := uuid.New()
+	user := newLifecycleUser(t, tenantID, "ana", model.UserStatusPending, time.Now().UTC())
+	repo.add(user)
+
+	invited, err := service.Invite(ctx, tenantID, user.ID, &actorID)
+	require.NoError(t, err)
+	assert.Equal(t, model.UserStatusInvited, invited.Status)
+	require.NotNil(t, invited.InvitationExpiresAt)
+	assert.WithinDuration(t, time.Now().UTC().AddDate(0, 0, impl.DefaultInvitationTTLDays), *invited.InvitationExpiresAt, time.Minute)
+
+	activated, err := service.Transition(ctx, &application.UserTransitionRequest{
+		TenantID: tenantID,
+		UserID:   user.ID,
+		Target:   model.UserStatusActive,
+		ActorID:  &actorID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.UserStatusActive, activated.Status)
+	assert.Nil(t, activated.InvitationExpiresAt)
+
+	assert.Equal(t, []string{event.TopicUserInvited, event.TopicUserActivated}, publisher.topics())
+
+	transitions, err := service.GetTransitions(ctx, tenantID, user.ID)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	assert.Equal(t, model.UserStatusPending, transitions[0].FromStatus)
+	assert.Equal(t, model.UserStatusInvited, transitions[1].FromStatus)
+	assert.False(t, transitions[1].Automated())
+}
+
+func TestUserLifecycleService_GuardedTransitions(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeUserLifecycleRepository()
+	publisher := &recordingPublisher{}
+	service := impl.NewUserLifecycleService(repo, publisher, model.UserLifecyclePolicy{})
+
+	tenantID := uuid.New()
+
+	t.Run("suspensão exige motivo", func(t *testing.T) {
+		user := newLifecycleUser(t, tenantID, "bruno", model.UserStatusActive, time.Now().UTC())
+		repo.add(user)
+
+		_, err := service.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: tenantID, UserID: user.ID, Target: model.UserStatusSuspended,
+		})
+		assert.ErrorIs(t, err, model.ErrUserTransitionReasonMissing)
+		assert.Equal(t, model.UserStatusActive, repo.status(user.ID))
+	})
+
+	t.Run("convite expirado não pode ser ativado", func(t *testing.T) {
+		user := newLifecycleUser(t, tenantID, "carla", model.UserStatusInvited, time.Now().UTC().AddDate(0, 0, -10))
+		expired := time.Now().UTC().AddDate(0, 0, -3)
+		user.InvitationExpiresAt = &expired
+		repo.add(user)
+
+		_, err := service.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: tenantID, UserID: user.ID, Target: model.UserStatusActive,
+		})
+		assert.ErrorIs(t, err, model.ErrUserInvitationExpired)
+	})
+
+	t.Run("convidado não pode ser suspenso", func(t *testing.T) {
+		user := newLifecycleUser(t, tenantID, "diogo", model.UserStatusInvited, time.Now().UTC())
+		repo.add(user)
+
+		_, err := service.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: tenantID, UserID: user.ID, Target: model.UserStatusSuspended, Reason: "offboarding",
+		})
+		assert.ErrorIs(t, err, model.ErrInvalidUserTransition)
+	})
+
+	t.Run("desprovisionado é terminal", func(t *testing.T) {
+		user := newLifecycleUser(t, tenantID, "eva", model.UserStatusSuspended, time.Now().UTC())
+		repo.add(user)
+
+		_, err := service.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: tenantID, UserID: user.ID, Target: model.UserStatusDeprovisioned, Reason: "desligamento",
+		})
+		require.NoError(t, err)
+
+		_, err = service.Transition(ctx, &application.UserTransitionRequest{
+			TenantID: tenantID, UserID: user.ID, Target: model.UserStatusActive,
+		})
+		assert.ErrorIs(t, err, model.ErrUserAccountDeprovisioned)
+	})
+
+	assert.Equal(t, []string{event.TopicUserDeprovisioned}, publisher.topics())
+}
+
+func TestUserLifecycleService_RunScheduledTransitions(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeUserLifecycleRepository()
+	publisher := &recordingPublisher{}
+	service := impl.NewUserLifecycleService(repo, publisher, model.UserLifecyclePolicy{})
+
+	now := time.Now().UTC()
+	tenantID := uuid.New()
+	otherTenantID := uuid.New()
+
+	require.NoError(t, service.SetPolicy(ctx, &model.UserLifecyclePolicy{
+		TenantID:                       tenantID,
+		SuspendAfterInactivityDays:     90,
+		DeprovisionAfterSuspensionDays: 30,
+	}))
+	// Tenant sem desprovisionamento agendado
+	require.NoError(t, service.SetPolicy(ctx, &model.UserLifecyclePolicy{
+		TenantID:                   otherTenantID,
+		SuspendAfterInactivityDays: 90,
+	}))
+
+	recent := newLifecycleUser(t, tenantID, "ativo-recente", model.UserStatusActive, now.AddDate(0, 0, -10))
+	inactive := newLifecycleUser(t, tenantID, "ativo-inativo", model.UserStatusActive, now.AddDate(0, 0, -120))
+	recentlySuspended := newLifecycleUser(t, tenantID, "suspenso-recente", model.UserStatusSuspended, now.AddDate(0, 0, -5))
+	longSuspended := newLifecycleUser(t, tenantID, "suspenso-antigo", model.UserStatusSuspended, now.AddDate(0, 0, -45))
+	otherSuspended := newLifecycleUser(t, otherTenantID, "outro-suspenso", model.UserStatusSuspended, now.AddDate(0, 0, -400))
+	for _, user := range []*model.User{recent, inactive, recentlySuspended, longSuspended, otherSuspended} {
+		repo.add(user)
+	}
+
+	// Um login recente mantém a conta ativa mesmo com estado antigo
+	loggedIn := newLifecycleUser(t, tenantID, "ativo-login", model.UserStatusActive, now.AddDate(0, 0, -200))
+	lastLogin := now.AddDate(0, 0, -1)
+	loggedIn.LastLoginAt = &lastLogin
+	repo.add(loggedIn)
+
+	result, err := service.RunScheduledTransitions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.TenantsProcessed)
+	assert.Equal(t, 1, result.Suspended)
+	assert.Equal(t, 1, result.Deprovisioned)
+	assert.Zero(t, result.Failed)
+
+	assert.Equal(t, model.UserStatusActive, repo.status(recent.ID))
+	assert.Equal(t, model.UserStatusActive, repo.status(loggedIn.ID))
+	assert.Equal(t, model.UserStatusSuspended, repo.status(inactive.ID))
+	assert.Equal(t, model.UserStatusSuspended, repo.status(recentlySuspended.ID))
+	assert.Equal(t, model.UserStatusDeprovisioned, repo.status(longSuspended.ID))
+	assert.Equal(t, model.UserStatusSuspended, repo.status(otherSuspended.ID))
+
+	// A conta suspensa nesta passagem não é desprovisionada na mesma execução
+	transitions, err := service.GetTransitions(ctx, tenantID, inactive.ID)
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, model.UserLifecycleReasonInactivity, transitions[0].Reason)
+	assert.True(t, transitions[0].Automated())
+
+	assert.ElementsMatch(t, []string{event.TopicUserSuspended, event.TopicUserDeprovisioned}, publisher.topics())
+}
+
+func TestUserLifecycleService_PolicyDefaults(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeUserLifecycleRepository()
+	service := impl.NewUserLifecycleService(repo, nil, model.UserLifecyclePolicy{InvitationTTLDays: 14})
+
+	tenantID := uuid.New()
+
+	policy, err := service.GetPolicy(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, policy.TenantID)
+	assert.Equal(t, 14, policy.InvitationTTLDays)
+	assert.Zero(t, policy.SuspendAfterInactivityDays)
+
+	err = service.SetPolicy(ctx, &model.UserLifecyclePolicy{TenantID: tenantID, SuspendAfterInactivityDays: -1})
+	assert.ErrorIs(t, err, model.ErrInvalidUserLifecyclePolicy)
+
+	result, err := service.RunScheduledTransitions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.TenantsProcessed)
+}
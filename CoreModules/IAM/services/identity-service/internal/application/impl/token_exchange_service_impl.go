@@ -0,0 +1,475 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão da troca de tokens
+const (
+	DefaultTokenExchangeMaxTTL      = 15 * time.Minute
+	DefaultTokenExchangeEventsLimit = 100
+	MaxTokenExchangeEventsLimit     = 1000
+)
+
+// TokenExchangeConfig configura a troca de tokens
+type TokenExchangeConfig struct {
+	// Emissor (iss) dos tokens delegados
+	Issuer string
+	// Validade máxima dos tokens delegados, aplicada sobre a das políticas
+	MaxTTL time.Duration
+	// Resolve as claims personalizadas atuais do titular; sem ele, os tokens delegados
+	// repetem as claims personalizadas do token do titular
+	ClaimsResolver application.UserClaimsResolver
+}
+
+// DefaultTokenExchangeConfig retorna a configuração padrão da troca de tokens
+func DefaultTokenExchangeConfig() TokenExchangeConfig {
+	return TokenExchangeConfig{
+		MaxTTL: DefaultTokenExchangeMaxTTL,
+	}
+}
+
+// TokenExchangeServiceImpl implementa a interface TokenExchangeService
+type TokenExchangeServiceImpl struct {
+	repository repository.TokenExchangeRepository
+	codec      application.TokenExchangeCodec
+	config     TokenExchangeConfig
+	now        func() time.Time
+}
+
+// NewTokenExchangeService cria uma nova instância de TokenExchangeService
+// O codec lê os tokens apresentados e assina os tokens delegados. Uma validade máxima não
+// positiva ou superior a model.MaxTokenExchangeTTL usa o padrão
+func NewTokenExchangeService(repo repository.TokenExchangeRepository, codec application.TokenExchangeCodec, config TokenExchangeConfig) application.TokenExchangeService {
+	if config.MaxTTL <= 0 || config.MaxTTL > model.MaxTokenExchangeTTL {
+		config.MaxTTL = DefaultTokenExchangeConfig().MaxTTL
+	}
+
+	return &TokenExchangeServiceImpl{
+		repository: repo,
+		codec:      codec,
+		config:     config,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Exchange troca o token do titular e o do serviço que age por um token delegado
+//
+// O token do serviço tem de pertencer ao tenant do titular e o serviço tem de estar autorizado
+// pela política da audiência pedida. Quando o token do titular já é delegado, tem de se destinar
+// ao serviço que age, e a cadeia de atores resultante não pode exceder a profundidade da política.
+// Os escopos são reduzidos aos da política e aos do token do titular, e a validade é a menor
+// entre a da política, a configurada e a que resta ao token do titular.
+func (s *TokenExchangeServiceImpl) Exchange(ctx context.Context, req *application.TokenExchangeRequest) (*application.TokenExchangeResponse, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeServiceImpl.Exchange")
+	defer span.End()
+
+	audience, err := validateTokenExchangeRequest(req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("audience", audience))
+
+	now := s.now()
+	subject, err := s.codec.Parse(req.SubjectToken)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidSubjectToken, err)
+	}
+	tenantID, err := uuid.Parse(subject.TenantID)
+	if err != nil {
+		span.SetStatus(codes.Error, "tenant do titular inválido")
+		return nil, fmt.Errorf("%w: tenant inválido", model.ErrInvalidSubjectToken)
+	}
+	span.SetAttributes(attribute.String("tenant_id", tenantID.String()))
+
+	event := &model.TokenExchangeEvent{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		Subject:    subject.Subject,
+		Audience:   audience,
+		IPAddress:  req.IPAddress,
+		OccurredAt: now,
+	}
+
+	actor, err := s.codec.Parse(req.ActorToken)
+	if err != nil {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: %v", model.ErrInvalidActorToken, err))
+	}
+	event.Actor = actor.ActorIdentifier()
+	if actor.TenantID != subject.TenantID {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: o token do serviço pertence a outro tenant", model.ErrInvalidActorToken))
+	}
+	if subject.Actor != nil && !containsString(subject.Audience, event.Actor) {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: o token delegado não se destina ao serviço que age", model.ErrInvalidSubjectToken))
+	}
+
+	act := &model.ActorClaim{Subject: actor.Subject, ClientID: actor.ClientID, Actor: subject.Actor}
+	event.ActorChain = act.Chain()
+	span.SetAttributes(
+		attribute.String("actor", event.Actor),
+		attribute.Int("delegation_depth", act.Depth()),
+	)
+
+	policy, err := s.repository.GetPolicyByAudience(ctx, tenantID, audience)
+	if errors.Is(err, model.ErrTokenExchangePolicyNotFound) || (err == nil && !policy.Enabled) {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: %s", model.ErrTokenExchangeTargetNotAllowed, audience))
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao obter política de troca de tokens: %w", err)
+	}
+	event.PolicyID = &policy.ID
+
+	if !policy.AllowsActor(event.Actor) {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: %s", model.ErrTokenExchangeActorNotAllowed, event.Actor))
+	}
+	if act.Depth() > policy.MaxDelegationDepth {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: %d atores, máximo %d",
+			model.ErrDelegationDepthExceeded, act.Depth(), policy.MaxDelegationDepth))
+	}
+
+	scopes, err := policy.Downscope(subject, req.Scopes)
+	if err != nil {
+		return nil, s.deny(ctx, event, err)
+	}
+
+	ttl := minDuration(policy.MaxTTL(), s.config.MaxTTL)
+	if remaining := subject.ExpiresAt.Sub(now).Truncate(time.Second); remaining < ttl {
+		ttl = remaining
+	}
+	if ttl < time.Second {
+		return nil, s.deny(ctx, event, fmt.Errorf("%w: token expirado", model.ErrInvalidSubjectToken))
+	}
+
+	customClaims, err := s.subjectCustomClaims(ctx, tenantID, subject)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	claims := &model.TokenClaims{
+		TokenID:      uuid.NewString(),
+		Issuer:       s.config.Issuer,
+		Subject:      subject.Subject,
+		TenantID:     subject.TenantID,
+		Audience:     []string{audience},
+		Scopes:       scopes,
+		ClientID:     event.Actor,
+		Username:     subject.Username,
+		Market:       subject.Market,
+		MFALevel:     subject.MFALevel,
+		SessionID:    subject.SessionID,
+		AuthTime:     subject.AuthTime,
+		IssuedAt:     now,
+		ExpiresAt:    now.Add(ttl),
+		Actor:        act,
+		CustomClaims: customClaims,
+	}
+	token, err := s.codec.Sign(claims)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao assinar token delegado: %w", err)
+	}
+
+	// Um token delegado só é entregue depois de a troca ficar auditada
+	event.Outcome = model.TokenExchangeIssued
+	event.Scopes = scopes
+	event.TokenID = claims.TokenID
+	event.ExpiresAt = &claims.ExpiresAt
+	if err := s.repository.SaveEvent(ctx, event); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao registar troca de tokens: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("subject", subject.Subject).
+		Str("actor", event.Actor).
+		Str("audience", audience).
+		Strs("scopes", scopes).
+		Int("delegation_depth", act.Depth()).
+		Str("token_id", claims.TokenID).
+		Msg("Token delegado emitido")
+
+	return &application.TokenExchangeResponse{
+		AccessToken:     token,
+		IssuedTokenType: model.TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int(ttl / time.Second),
+		Scope:           strings.Join(scopes, " "),
+	}, nil
+}
+
+// subjectCustomClaims retorna as claims personalizadas do token delegado
+// Os atributos do titular são relidos para que um atributo retirado não passe aos tokens delegados;
+// os titulares que não são usuários do tenant, como as contas de serviço, mantêm as claims do seu token
+func (s *TokenExchangeServiceImpl) subjectCustomClaims(ctx context.Context, tenantID uuid.UUID, subject *model.TokenClaims) (map[string]interface{}, error) {
+	if s.config.ClaimsResolver == nil {
+		return subject.CustomClaims, nil
+	}
+	userID, err := uuid.Parse(subject.Subject)
+	if err != nil {
+		return subject.CustomClaims, nil
+	}
+
+	claims, err := s.config.ClaimsResolver.ResolveClaims(ctx, tenantID, userID)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return subject.CustomClaims, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver claims personalizadas do titular: %w", err)
+	}
+	return claims, nil
+}
+
+// ListPolicies recupera as políticas de troca de tokens do tenant
+func (s *TokenExchangeServiceImpl) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.TokenExchangePolicy, error) {
+	policies, err := s.repository.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar políticas de troca de tokens: %w", err)
+	}
+	if policies == nil {
+		policies = []*model.TokenExchangePolicy{}
+	}
+	return policies, nil
+}
+
+// GetPolicy recupera uma política de troca de tokens do tenant
+func (s *TokenExchangeServiceImpl) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.TokenExchangePolicy, error) {
+	policy, err := s.repository.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		if errors.Is(err, model.ErrTokenExchangePolicyNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter política de troca de tokens: %w", err)
+	}
+	return policy, nil
+}
+
+// CreatePolicy cria uma política de troca de tokens
+func (s *TokenExchangeServiceImpl) CreatePolicy(ctx context.Context, req *application.SaveTokenExchangePolicyRequest) (*model.TokenExchangePolicy, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeServiceImpl.CreatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("audience", req.Audience),
+	))
+	defer span.End()
+
+	now := s.now()
+	policy := &model.TokenExchangePolicy{
+		ID:                 uuid.New(),
+		TenantID:           req.TenantID,
+		Audience:           req.Audience,
+		Description:        req.Description,
+		AllowedActors:      req.AllowedActors,
+		AllowedScopes:      req.AllowedScopes,
+		MaxTTLSeconds:      req.MaxTTLSeconds,
+		MaxDelegationDepth: req.MaxDelegationDepth,
+		Enabled:            req.Enabled,
+		CreatedBy:          req.ActorID,
+		UpdatedBy:          req.ActorID,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	policy.Normalize()
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.CreatePolicy(ctx, policy); err != nil {
+		if errors.Is(err, model.ErrTokenExchangePolicyConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar política de troca de tokens: %w", err)
+	}
+
+	s.logPolicyChange(policy, "criada")
+	return policy, nil
+}
+
+// UpdatePolicy altera uma política de troca de tokens
+func (s *TokenExchangeServiceImpl) UpdatePolicy(ctx context.Context, req *application.SaveTokenExchangePolicyRequest) (*model.TokenExchangePolicy, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeServiceImpl.UpdatePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("policy_id", req.PolicyID.String()),
+	))
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, req.TenantID, req.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Audience = req.Audience
+	policy.Description = req.Description
+	policy.AllowedActors = req.AllowedActors
+	policy.AllowedScopes = req.AllowedScopes
+	policy.MaxTTLSeconds = req.MaxTTLSeconds
+	policy.MaxDelegationDepth = req.MaxDelegationDepth
+	policy.Enabled = req.Enabled
+	policy.UpdatedBy = req.ActorID
+	policy.UpdatedAt = s.now()
+	policy.Normalize()
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdatePolicy(ctx, policy); err != nil {
+		if errors.Is(err, model.ErrTokenExchangePolicyNotFound) || errors.Is(err, model.ErrTokenExchangePolicyConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao gravar política de troca de tokens: %w", err)
+	}
+
+	s.logPolicyChange(policy, "alterada")
+	return policy, nil
+}
+
+// DeletePolicy remove uma política de troca de tokens
+func (s *TokenExchangeServiceImpl) DeletePolicy(ctx context.Context, tenantID, policyID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TokenExchangeServiceImpl.DeletePolicy", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("policy_id", policyID.String()),
+	))
+	defer span.End()
+
+	if err := s.repository.DeletePolicy(ctx, tenantID, policyID); err != nil {
+		if errors.Is(err, model.ErrTokenExchangePolicyNotFound) {
+			return err
+		}
+		return fmt.Errorf("erro ao remover política de troca de tokens: %w", err)
+	}
+
+	log.Info().
+		Str("tenant_id", tenantID.String()).
+		Str("policy_id", policyID.String()).
+		Str("actor_id", actorID.String()).
+		Msg("Política de troca de tokens removida")
+	return nil
+}
+
+// ListEvents recupera o registo de auditoria das trocas de tokens do tenant, mais recentes primeiro
+func (s *TokenExchangeServiceImpl) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.TokenExchangeEventFilter) ([]*model.TokenExchangeEvent, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeServiceImpl.ListEvents", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	))
+	defer span.End()
+
+	if filter.Outcome != "" && !filter.Outcome.IsValid() {
+		return nil, fmt.Errorf("%w: resultado %q desconhecido", model.ErrInvalidTokenExchangeFilter, filter.Outcome)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultTokenExchangeEventsLimit
+	}
+	if filter.Limit > MaxTokenExchangeEventsLimit {
+		filter.Limit = MaxTokenExchangeEventsLimit
+	}
+
+	events, err := s.repository.ListEvents(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar registo de trocas de tokens: %w", err)
+	}
+	if events == nil {
+		events = []*model.TokenExchangeEvent{}
+	}
+	return events, nil
+}
+
+// deny regista a troca recusada e retorna o erro da recusa
+// Uma falha no registo não altera a resposta ao serviço, que é recusado de qualquer forma
+func (s *TokenExchangeServiceImpl) deny(ctx context.Context, event *model.TokenExchangeEvent, reason error) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(codes.Error, reason.Error())
+
+	event.Outcome = model.TokenExchangeDenied
+	event.Reason = model.TokenExchangeErrorCode(reason)
+	if err := s.repository.SaveEvent(ctx, event); err != nil {
+		span.RecordError(err)
+		log.Error().Err(err).
+			Str("tenant_id", event.TenantID.String()).
+			Str("actor", event.Actor).
+			Str("audience", event.Audience).
+			Msg("Erro ao registar troca de tokens recusada")
+	}
+
+	log.Warn().
+		Str("tenant_id", event.TenantID.String()).
+		Str("subject", event.Subject).
+		Str("actor", event.Actor).
+		Str("audience", event.Audience).
+		Str("reason", event.Reason).
+		Err(reason).
+		Msg("Troca de tokens recusada")
+	return reason
+}
+
+// logPolicyChange regista a criação ou alteração de uma política de troca de tokens
+func (s *TokenExchangeServiceImpl) logPolicyChange(policy *model.TokenExchangePolicy, change string) {
+	log.Info().
+		Str("tenant_id", policy.TenantID.String()).
+		Str("policy_id", policy.ID.String()).
+		Str("audience", policy.Audience).
+		Strs("allowed_actors", policy.AllowedActors).
+		Strs("allowed_scopes", policy.AllowedScopes).
+		Int("max_ttl_seconds", policy.MaxTTLSeconds).
+		Int("max_delegation_depth", policy.MaxDelegationDepth).
+		Bool("enabled", policy.Enabled).
+		Str("actor_id", policy.UpdatedBy.String()).
+		Msgf("Política de troca de tokens %s", change)
+}
+
+// validateTokenExchangeRequest valida os parâmetros do pedido e retorna a audiência pedida,
+// indicada em audience ou, na sua falta, em resource
+func validateTokenExchangeRequest(req *application.TokenExchangeRequest) (string, error) {
+	if req.GrantType != model.GrantTypeTokenExchange {
+		return "", fmt.Errorf("%w: grant_type não suportado", model.ErrInvalidTokenExchangeRequest)
+	}
+	if req.SubjectToken == "" || !isSupportedTokenType(req.SubjectTokenType) {
+		return "", fmt.Errorf("%w: subject_token e subject_token_type suportado são obrigatórios", model.ErrInvalidTokenExchangeRequest)
+	}
+	if req.ActorToken == "" || !isSupportedTokenType(req.ActorTokenType) {
+		return "", fmt.Errorf("%w: a delegação exige actor_token e actor_token_type suportado", model.ErrInvalidTokenExchangeRequest)
+	}
+	if req.RequestedTokenType != "" && !isSupportedTokenType(req.RequestedTokenType) {
+		return "", fmt.Errorf("%w: requested_token_type não suportado", model.ErrInvalidTokenExchangeRequest)
+	}
+
+	audience := strings.TrimSpace(req.Audience)
+	if audience == "" {
+		audience = strings.TrimSpace(req.Resource)
+	}
+	if audience == "" {
+		return "", fmt.Errorf("%w: audience ou resource é obrigatório", model.ErrInvalidTokenExchangeRequest)
+	}
+	return audience, nil
+}
+
+// isSupportedTokenType indica se o tipo de token é aceite na troca
+func isSupportedTokenType(tokenType string) bool {
+	return tokenType == model.TokenTypeAccessToken || tokenType == model.TokenTypeJWT
+}
+
+// containsString indica se o valor está na lista
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
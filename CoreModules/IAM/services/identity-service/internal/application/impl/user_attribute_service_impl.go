@@ -0,0 +1,369 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão dos atributos dos usuários
+const (
+	DefaultUserAttributeSchemaCacheTTL = time.Minute
+)
+
+// cachedUserAttributeCatalog guarda os esquemas dos atributos de um tenant
+type cachedUserAttributeCatalog struct {
+	schemas   []*model.UserAttributeSchema
+	catalog   *model.UserAttributeCatalog
+	expiresAt time.Time
+}
+
+// UserAttributeServiceImpl implementa a interface UserAttributeService
+// Os esquemas do tenant ficam em cache, porque são consultados em cada decisão de autorização;
+// o cache do tenant é descartado nas alterações feitas por esta instância
+type UserAttributeServiceImpl struct {
+	repository repository.UserAttributeRepository
+	cacheTTL   time.Duration
+	now        func() time.Time
+
+	mutex    sync.Mutex
+	catalogs map[uuid.UUID]cachedUserAttributeCatalog
+}
+
+// NewUserAttributeService cria uma nova instância de UserAttributeService
+// Um tempo de cache não positivo usa o valor padrão
+func NewUserAttributeService(repo repository.UserAttributeRepository, cacheTTL time.Duration) application.UserAttributeService {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultUserAttributeSchemaCacheTTL
+	}
+
+	return &UserAttributeServiceImpl{
+		repository: repo,
+		cacheTTL:   cacheTTL,
+		now:        func() time.Time { return time.Now().UTC() },
+		catalogs:   make(map[uuid.UUID]cachedUserAttributeCatalog),
+	}
+}
+
+// ListSchemas recupera os esquemas dos atributos do tenant
+func (s *UserAttributeServiceImpl) ListSchemas(ctx context.Context, tenantID uuid.UUID) ([]*model.UserAttributeSchema, error) {
+	cached, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make([]*model.UserAttributeSchema, len(cached.schemas))
+	for i, schema := range cached.schemas {
+		copied := *schema
+		schemas[i] = &copied
+	}
+	return schemas, nil
+}
+
+// GetSchema recupera um esquema do tenant
+func (s *UserAttributeServiceImpl) GetSchema(ctx context.Context, tenantID, schemaID uuid.UUID) (*model.UserAttributeSchema, error) {
+	schema, err := s.repository.GetSchema(ctx, tenantID, schemaID)
+	if err != nil {
+		if errors.Is(err, model.ErrUserAttributeSchemaNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter esquema de atributo: %w", err)
+	}
+	return schema, nil
+}
+
+// CreateSchema cria um esquema de atributo
+func (s *UserAttributeServiceImpl) CreateSchema(ctx context.Context, req *application.SaveUserAttributeSchemaRequest) (*model.UserAttributeSchema, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeServiceImpl.CreateSchema", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_attribute.name", req.Name),
+	))
+	defer span.End()
+
+	now := s.now()
+	schema := &model.UserAttributeSchema{
+		ID:        uuid.New(),
+		TenantID:  req.TenantID,
+		Name:      req.Name,
+		Type:      req.Type,
+		CreatedBy: req.ActorID,
+		CreatedAt: now,
+	}
+	applyUserAttributeSchemaRequest(schema, req, now)
+	schema.Normalize()
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.ListSchemas(ctx, req.TenantID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao listar esquemas de atributos: %w", err)
+	}
+	if len(existing) >= model.MaxUserAttributeSchemas {
+		return nil, fmt.Errorf("%w: máximo %d", model.ErrUserAttributeSchemaLimit, model.MaxUserAttributeSchemas)
+	}
+
+	if err := s.repository.CreateSchema(ctx, schema); err != nil {
+		if errors.Is(err, model.ErrUserAttributeSchemaConflict) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar esquema de atributo: %w", err)
+	}
+
+	s.invalidate(schema.TenantID)
+	logUserAttributeSchemaChange(schema, req.ActorID, "criado")
+	return schema, nil
+}
+
+// UpdateSchema altera as restrições, a obrigatoriedade e a claim de um esquema
+func (s *UserAttributeServiceImpl) UpdateSchema(ctx context.Context, req *application.SaveUserAttributeSchemaRequest) (*model.UserAttributeSchema, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeServiceImpl.UpdateSchema", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_attribute.schema_id", req.SchemaID.String()),
+	))
+	defer span.End()
+
+	schema, err := s.GetSchema(ctx, req.TenantID, req.SchemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Os valores gravados foram validados pelo nome e pelo tipo atuais
+	requested := model.UserAttributeSchema{Name: req.Name, Type: req.Type}
+	requested.Normalize()
+	if requested.Name != schema.Name || requested.Type != schema.Type {
+		return nil, fmt.Errorf("%w: o nome e o tipo de um atributo não podem ser alterados", model.ErrInvalidUserAttributeSchema)
+	}
+
+	applyUserAttributeSchemaRequest(schema, req, s.now())
+	schema.Normalize()
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateSchema(ctx, schema); err != nil {
+		if errors.Is(err, model.ErrUserAttributeSchemaNotFound) || errors.Is(err, model.ErrUserAttributeSchemaConflict) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar esquema de atributo: %w", err)
+	}
+
+	s.invalidate(schema.TenantID)
+	logUserAttributeSchemaChange(schema, req.ActorID, "alterado")
+	return schema, nil
+}
+
+// DeleteSchema remove o esquema e o atributo de todos os usuários do tenant
+func (s *UserAttributeServiceImpl) DeleteSchema(ctx context.Context, tenantID, schemaID, actorID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserAttributeServiceImpl.DeleteSchema", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_attribute.schema_id", schemaID.String()),
+	))
+	defer span.End()
+
+	schema, err := s.GetSchema(ctx, tenantID, schemaID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.DeleteSchema(ctx, tenantID, schemaID); err != nil {
+		if errors.Is(err, model.ErrUserAttributeSchemaNotFound) {
+			return err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("erro ao remover esquema de atributo: %w", err)
+	}
+
+	s.invalidate(tenantID)
+	logUserAttributeSchemaChange(schema, actorID, "removido")
+	return nil
+}
+
+// GetUserAttributes recupera os atributos gravados do usuário
+func (s *UserAttributeServiceImpl) GetUserAttributes(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributes, error) {
+	attributes, err := s.repository.GetUserAttributes(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, model.ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("erro ao obter atributos do usuário: %w", err)
+	}
+	if attributes.Values == nil {
+		attributes.Values = map[string]interface{}{}
+	}
+	return attributes, nil
+}
+
+// SetUserAttributes valida os atributos contra os esquemas do tenant e substitui os do usuário
+func (s *UserAttributeServiceImpl) SetUserAttributes(ctx context.Context, req *application.SetUserAttributesRequest) (*model.UserAttributes, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeServiceImpl.SetUserAttributes", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+	))
+	defer span.End()
+
+	if req.TenantID == uuid.Nil {
+		return nil, model.ErrInvalidTenantID
+	}
+
+	// Os esquemas são lidos do repositório para validar a escrita contra as alterações mais recentes
+	s.invalidate(req.TenantID)
+	cached, err := s.load(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	values, err := cached.catalog.Validate(req.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	actorID := req.ActorID
+	attributes := &model.UserAttributes{
+		TenantID:  req.TenantID,
+		UserID:    req.UserID,
+		Values:    values,
+		UpdatedBy: &actorID,
+		UpdatedAt: &now,
+	}
+	if err := s.repository.SaveUserAttributes(ctx, attributes); err != nil {
+		if errors.Is(err, model.ErrUserNotFound) {
+			return nil, err
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("erro ao gravar atributos do usuário: %w", err)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	log.Info().
+		Str("tenant_id", req.TenantID.String()).
+		Str("user_id", req.UserID.String()).
+		Strs("attributes", names).
+		Str("actor_id", req.ActorID.String()).
+		Msg("Atributos do usuário alterados")
+
+	return attributes, nil
+}
+
+// ResolveAttributes retorna os atributos válidos do usuário, para o contexto das políticas ABAC
+func (s *UserAttributeServiceImpl) ResolveAttributes(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error) {
+	catalog, values, err := s.resolve(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Current(values), nil
+}
+
+// ResolveClaims retorna as claims dos atributos do usuário cujo esquema indica uma claim
+func (s *UserAttributeServiceImpl) ResolveClaims(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error) {
+	catalog, values, err := s.resolve(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Claims(values), nil
+}
+
+// resolve carrega os esquemas do tenant e os atributos gravados do usuário
+// Sem esquemas no tenant não é feita nenhuma consulta aos atributos do usuário
+func (s *UserAttributeServiceImpl) resolve(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributeCatalog, map[string]interface{}, error) {
+	cached, err := s.load(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cached.schemas) == 0 {
+		return cached.catalog, nil, nil
+	}
+
+	attributes, err := s.GetUserAttributes(ctx, tenantID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cached.catalog, attributes.Values, nil
+}
+
+// load retorna os esquemas do tenant em cache, recarregando-os quando o cache expirou
+func (s *UserAttributeServiceImpl) load(ctx context.Context, tenantID uuid.UUID) (cachedUserAttributeCatalog, error) {
+	if tenantID == uuid.Nil {
+		return cachedUserAttributeCatalog{}, model.ErrInvalidTenantID
+	}
+
+	now := s.now()
+	s.mutex.Lock()
+	cached, ok := s.catalogs[tenantID]
+	s.mutex.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	schemas, err := s.repository.ListSchemas(ctx, tenantID)
+	if err != nil {
+		return cachedUserAttributeCatalog{}, fmt.Errorf("erro ao carregar esquemas de atributos: %w", err)
+	}
+	if schemas == nil {
+		schemas = []*model.UserAttributeSchema{}
+	}
+	cached = cachedUserAttributeCatalog{
+		schemas:   schemas,
+		catalog:   model.NewUserAttributeCatalog(schemas),
+		expiresAt: now.Add(s.cacheTTL),
+	}
+	s.mutex.Lock()
+	s.catalogs[tenantID] = cached
+	s.mutex.Unlock()
+	return cached, nil
+}
+
+// invalidate descarta os esquemas do tenant em cache
+func (s *UserAttributeServiceImpl) invalidate(tenantID uuid.UUID) {
+	s.mutex.Lock()
+	delete(s.catalogs, tenantID)
+	s.mutex.Unlock()
+}
+
+// applyUserAttributeSchemaRequest copia para o esquema os campos alteráveis do pedido
+func applyUserAttributeSchemaRequest(schema *model.UserAttributeSchema, req *application.SaveUserAttributeSchemaRequest, now time.Time) {
+	schema.DisplayName = req.DisplayName
+	schema.Description = req.Description
+	schema.Required = req.Required
+	schema.EnumValues = req.EnumValues
+	schema.Pattern = req.Pattern
+	schema.MaxLength = req.MaxLength
+	schema.MinValue = req.MinValue
+	schema.MaxValue = req.MaxValue
+	schema.ClaimName = req.ClaimName
+	schema.UpdatedBy = req.ActorID
+	schema.UpdatedAt = now
+}
+
+// logUserAttributeSchemaChange regista a criação, alteração ou remoção de um esquema de atributo
+func logUserAttributeSchemaChange(schema *model.UserAttributeSchema, actorID uuid.UUID, change string) {
+	log.Info().
+		Str("tenant_id", schema.TenantID.String()).
+		Str("schema_id", schema.ID.String()).
+		Str("name", schema.Name).
+		Str("type", string(schema.Type)).
+		Bool("required", schema.Required).
+		Str("claim_name", schema.ClaimName).
+		Str("actor_id", actorID.String()).
+		Msgf("Esquema de atributo %s", change)
+}
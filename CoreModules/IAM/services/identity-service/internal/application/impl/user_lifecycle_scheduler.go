@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// Intervalo padrão entre execuções das transições automáticas
+const DefaultUserLifecycleInterval = time.Hour
+
+// UserLifecycleScheduler executa periodicamente as transições automáticas do ciclo de vida
+type UserLifecycleScheduler struct {
+	service  application.UserLifecycleService
+	interval time.Duration
+}
+
+// NewUserLifecycleScheduler cria o agendador de suspensões e desprovisionamentos
+// Um intervalo não positivo usa DefaultUserLifecycleInterval
+func NewUserLifecycleScheduler(service application.UserLifecycleService, interval time.Duration) *UserLifecycleScheduler {
+	if interval <= 0 {
+		interval = DefaultUserLifecycleInterval
+	}
+	return &UserLifecycleScheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start executa as transições no arranque e a cada intervalo até o contexto ser cancelado
+func (s *UserLifecycleScheduler) Start(ctx context.Context) {
+	// As transições agendadas abrangem os usuários de todos os tenants
+	ctx = requestctx.WithSystemScope(ctx, "user_lifecycle_scheduler")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run executa uma passagem e regista o resultado
+func (s *UserLifecycleScheduler) run(ctx context.Context) {
+	result, err := s.service.RunScheduledTransitions(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao executar transições automáticas do ciclo de vida de usuários")
+		return
+	}
+
+	log.Info().
+		Int("tenants_processed", result.TenantsProcessed).
+		Int("suspended", result.Suspended).
+		Int("deprovisioned", result.Deprovisioned).
+		Int("failed", result.Failed).
+		Dur("duration", time.Since(result.StartedAt)).
+		Msg("Transições automáticas do ciclo de vida de usuários concluídas")
+}
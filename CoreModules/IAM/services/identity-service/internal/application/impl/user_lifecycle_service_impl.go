@@ -0,0 +1,285 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Valores padrão do ciclo de vida de usuários
+const (
+	DefaultInvitationTTLDays      = 7
+	DefaultUserLifecycleBatchSize = 100
+)
+
+// UserLifecycleServiceImpl implementa a interface UserLifecycleService
+type UserLifecycleServiceImpl struct {
+	repository     repository.UserLifecycleRepository
+	eventPublisher event.Publisher
+	defaultPolicy  model.UserLifecyclePolicy
+	batchSize      int
+	now            func() time.Time
+}
+
+// NewUserLifecycleService cria uma nova instância de UserLifecycleService
+// A política padrão aplica-se aos tenants sem política própria; as transições automáticas
+// só são executadas para tenants com política configurada
+func NewUserLifecycleService(
+	repo repository.UserLifecycleRepository,
+	eventPublisher event.Publisher,
+	defaultPolicy model.UserLifecyclePolicy,
+) application.UserLifecycleService {
+	if defaultPolicy.InvitationTTLDays <= 0 {
+		defaultPolicy.InvitationTTLDays = DefaultInvitationTTLDays
+	}
+	return &UserLifecycleServiceImpl{
+		repository:     repo,
+		eventPublisher: eventPublisher,
+		defaultPolicy:  defaultPolicy,
+		batchSize:      DefaultUserLifecycleBatchSize,
+		now:            func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Invite coloca um usuário recém-criado no estado convidado
+func (s *UserLifecycleServiceImpl) Invite(ctx context.Context, tenantID, userID uuid.UUID, actorID *uuid.UUID) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleServiceImpl.Invite", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	policy, err := s.GetPolicy(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repository.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter usuário: %w", err)
+	}
+
+	now := s.now()
+	transition, err := user.Invite(policy.InvitationExpiry(now), actorID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, user, transition); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Transition aplica uma transição guardada ao usuário
+func (s *UserLifecycleServiceImpl) Transition(ctx context.Context, req *application.UserTransitionRequest) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleServiceImpl.Transition", trace.WithAttributes(
+		attribute.String("tenant_id", req.TenantID.String()),
+		attribute.String("user_id", req.UserID.String()),
+		attribute.String("target_status", string(req.Target)),
+	))
+	defer span.End()
+
+	// O estado convidado só é atribuído por Invite, que define a validade do convite
+	if req.Target == model.UserStatusInvited {
+		return nil, fmt.Errorf("%w: use o convite para o estado %s", model.ErrInvalidUserTransition, req.Target)
+	}
+
+	user, err := s.repository.GetUser(ctx, req.TenantID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter usuário: %w", err)
+	}
+
+	transition, err := user.TransitionTo(req.Target, req.Reason, req.ActorID, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, user, transition); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetTransitions recupera o histórico de transições do usuário
+func (s *UserLifecycleServiceImpl) GetTransitions(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.UserLifecycleTransition, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleServiceImpl.GetTransitions", trace.WithAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	transitions, err := s.repository.ListTransitions(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter transições do usuário: %w", err)
+	}
+	return transitions, nil
+}
+
+// GetPolicy recupera a política do tenant ou a política padrão
+func (s *UserLifecycleServiceImpl) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.UserLifecyclePolicy, error) {
+	policy, err := s.repository.GetPolicy(ctx, tenantID)
+	if errors.Is(err, model.ErrUserLifecyclePolicyNotFound) {
+		defaults := s.defaultPolicy
+		defaults.TenantID = tenantID
+		return &defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter política de ciclo de vida: %w", err)
+	}
+	return policy, nil
+}
+
+// SetPolicy configura a política de ciclo de vida do tenant
+func (s *UserLifecycleServiceImpl) SetPolicy(ctx context.Context, policy *model.UserLifecyclePolicy) error {
+	ctx, span := tracer.Start(ctx, "UserLifecycleServiceImpl.SetPolicy", trace.WithAttributes(
+		attribute.String("tenant_id", policy.TenantID.String()),
+	))
+	defer span.End()
+
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if policy.InvitationTTLDays == 0 {
+		policy.InvitationTTLDays = s.defaultPolicy.InvitationTTLDays
+	}
+	policy.UpdatedAt = s.now()
+
+	if err := s.repository.SavePolicy(ctx, policy); err != nil {
+		return fmt.Errorf("erro ao gravar política de ciclo de vida: %w", err)
+	}
+	return nil
+}
+
+// RunScheduledTransitions executa as transições automáticas de todos os tenants com política
+func (s *UserLifecycleServiceImpl) RunScheduledTransitions(ctx context.Context) (*application.UserLifecycleSweepResult, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleServiceImpl.RunScheduledTransitions")
+	defer span.End()
+
+	result := &application.UserLifecycleSweepResult{StartedAt: s.now()}
+
+	policies, err := s.repository.ListPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar políticas de ciclo de vida: %w", err)
+	}
+
+	for _, policy := range policies {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		// Suspender primeiro: uma conta suspensa agora só é desprovisionada após o prazo completo
+		if cutoff, enabled := policy.InactivityCutoff(result.StartedAt); enabled {
+			suspended, failed := s.sweep(ctx, policy.TenantID, model.UserStatusSuspended, model.UserLifecycleReasonInactivity,
+				func(limit int) ([]*model.User, error) {
+					return s.repository.FindInactiveUsers(ctx, policy.TenantID, cutoff, limit)
+				})
+			result.Suspended += suspended
+			result.Failed += failed
+		}
+
+		if cutoff, enabled := policy.DeprovisionCutoff(result.StartedAt); enabled {
+			deprovisioned, failed := s.sweep(ctx, policy.TenantID, model.UserStatusDeprovisioned, model.UserLifecycleReasonScheduledDeprovisioning,
+				func(limit int) ([]*model.User, error) {
+					return s.repository.FindSuspendedUsers(ctx, policy.TenantID, cutoff, limit)
+				})
+			result.Deprovisioned += deprovisioned
+			result.Failed += failed
+		}
+
+		result.TenantsProcessed++
+	}
+
+	span.SetAttributes(
+		attribute.Int("tenants_processed", result.TenantsProcessed),
+		attribute.Int("suspended", result.Suspended),
+		attribute.Int("deprovisioned", result.Deprovisioned),
+		attribute.Int("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// sweep aplica a transição automática aos usuários devolvidos por find, em lotes,
+// até não restarem candidatos ou um lote inteiro falhar
+func (s *UserLifecycleServiceImpl) sweep(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	target model.UserStatus,
+	reason string,
+	find func(limit int) ([]*model.User, error),
+) (applied, failed int) {
+	for ctx.Err() == nil {
+		users, err := find(s.batchSize)
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("target_status", string(target)).
+				Msg("Erro ao obter usuários para transição automática")
+			return applied, failed + 1
+		}
+
+		batchApplied := 0
+		for _, user := range users {
+			transition, err := user.TransitionTo(target, reason, nil, s.now())
+			if err == nil {
+				err = s.save(ctx, user, transition)
+			}
+			if err != nil {
+				log.Warn().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("user_id", user.ID.String()).
+					Str("target_status", string(target)).
+					Msg("Falha na transição automática do usuário")
+				failed++
+				continue
+			}
+			batchApplied++
+		}
+		applied += batchApplied
+
+		// Os usuários que falharam continuariam a ser devolvidos: parar em vez de repetir o lote
+		if len(users) < s.batchSize || batchApplied == 0 {
+			break
+		}
+	}
+	return applied, failed
+}
+
+// save grava a transição e publica o evento de ciclo de vida
+func (s *UserLifecycleServiceImpl) save(ctx context.Context, user *model.User, transition *model.UserLifecycleTransition) error {
+	if err := s.repository.SaveTransition(ctx, user, transition); err != nil {
+		return fmt.Errorf("erro ao gravar transição do usuário: %w", err)
+	}
+
+	s.publishUserLifecycleEvent(ctx, user, transition)
+	return nil
+}
+
+// publishUserLifecycleEvent publica o evento da transição para sincronização com RH/ITSM
+func (s *UserLifecycleServiceImpl) publishUserLifecycleEvent(ctx context.Context, user *model.User, transition *model.UserLifecycleTransition) {
+	if s.eventPublisher == nil {
+		return
+	}
+
+	evt := event.NewUserLifecycleEvent(user, transition)
+	err := s.eventPublisher.Publish(ctx, evt)
+	if err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", transition.TenantID.String()).
+			Str("user_id", transition.UserID.String()).
+			Str("event_type", evt.GetType()).
+			Msg("Erro ao publicar evento de ciclo de vida do usuário")
+	}
+}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da aceitação de documentos legais
+var (
+	ErrLegalDocumentNotFound        = model.ErrLegalDocumentNotFound
+	ErrInvalidLegalDocument         = model.ErrInvalidLegalDocument
+	ErrLegalDocumentVersionExists   = model.ErrLegalDocumentVersionExists
+	ErrLegalDocumentNotCurrent      = model.ErrLegalDocumentNotCurrent
+	ErrLegalDocumentHashMismatch    = model.ErrLegalDocumentHashMismatch
+	ErrInvalidLegalAcceptanceFilter = model.ErrInvalidLegalAcceptanceFilter
+)
+
+// LegalAcceptanceGate indica, no fim de um login, os documentos que o usuário tem de aceitar
+// antes de o login ser concluído. A autenticação sem senha e a federação SAML usam-no
+type LegalAcceptanceGate interface {
+	// LoginPendingDocuments retorna os documentos obrigatórios em vigor que o usuário ainda não
+	// aceitou; retorna vazio quando o bloqueio do login está desativado
+	LoginPendingDocuments(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LegalDocument, error)
+}
+
+// PublishLegalDocumentRequest representa a publicação de uma nova versão de um documento legal
+// Sem EffectiveAt, a versão entra em vigor no momento da publicação
+type PublishLegalDocumentRequest struct {
+	TenantID    uuid.UUID               `json:"tenant_id"`
+	Type        model.LegalDocumentType `json:"type"`
+	Version     string                  `json:"version"`
+	Title       string                  `json:"title"`
+	URL         string                  `json:"url"`
+	ContentHash string                  `json:"content_hash"`
+	Required    bool                    `json:"required"`
+	EffectiveAt *time.Time              `json:"effective_at,omitempty"`
+	PublishedBy uuid.UUID               `json:"published_by"`
+}
+
+// AcceptLegalDocumentRequest representa a aceitação de um documento pelo usuário autenticado
+// DocumentHash é o hash do conteúdo apresentado ao usuário e tem de corresponder ao publicado
+type AcceptLegalDocumentRequest struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	DocumentID   uuid.UUID `json:"document_id"`
+	DocumentHash string    `json:"document_hash"`
+	IPAddress    string    `json:"ip_address,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+}
+
+// LegalConsentService define a interface de serviço para a aceitação de documentos legais
+type LegalConsentService interface {
+	LegalAcceptanceGate
+
+	// PublishDocument publica uma nova versão de um documento legal do tenant
+	PublishDocument(ctx context.Context, req *PublishLegalDocumentRequest) (*model.LegalDocument, error)
+
+	// GetDocument recupera uma versão de um documento legal do tenant
+	GetDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalDocument, error)
+
+	// ListDocuments recupera as versões publicadas pelo tenant ou, com currentOnly, as versões em vigor
+	ListDocuments(ctx context.Context, tenantID uuid.UUID, currentOnly bool) ([]*model.LegalDocument, error)
+
+	// ListPendingDocuments recupera as versões em vigor que o usuário ainda não aceitou
+	ListPendingDocuments(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LegalDocument, error)
+
+	// Accept regista a aceitação da versão em vigor de um documento pelo usuário
+	Accept(ctx context.Context, req *AcceptLegalDocumentRequest) (*model.LegalAcceptance, error)
+
+	// ListAcceptances recupera os registos de aceitação do tenant
+	ListAcceptances(ctx context.Context, tenantID uuid.UUID, filter model.LegalAcceptanceFilter) ([]*model.LegalAcceptance, error)
+
+	// AcceptanceReport resume a aceitação de uma versão de um documento pelos usuários ativos do tenant
+	AcceptanceReport(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalAcceptanceReport, error)
+}
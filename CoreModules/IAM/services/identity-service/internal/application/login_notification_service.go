@@ -0,0 +1,123 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das notificações de login
+var (
+	ErrInvalidLoginNotificationSettings  = model.ErrInvalidLoginNotificationSettings
+	ErrInvalidLoginNotificationTemplate  = model.ErrInvalidLoginNotificationTemplate
+	ErrLoginNotificationTemplateNotFound = model.ErrLoginNotificationTemplateNotFound
+	ErrInvalidLoginResponseToken         = model.ErrInvalidLoginResponseToken
+)
+
+// LoginObserver é avisado dos logins concluídos
+// Os serviços de autenticação usam-no para ficar independentes das notificações de login
+type LoginObserver interface {
+	// ObserveLogin avalia o login e avisa o usuário quando vem de um contexto desconhecido
+	ObserveLogin(ctx context.Context, event *model.LoginEvent) error
+}
+
+// LoginObservers avisa vários observadores de cada login, pela ordem indicada
+// Todos os observadores são avisados mesmo quando um deles falha; as falhas são retornadas juntas
+type LoginObservers []LoginObserver
+
+// ObserveLogin avisa cada observador do login
+func (o LoginObservers) ObserveLogin(ctx context.Context, event *model.LoginEvent) error {
+	var errs []error
+	for _, observer := range o {
+		if observer == nil {
+			continue
+		}
+		if err := observer.ObserveLogin(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LoginNotificationMessage representa uma mensagem entregue a um usuário num canal
+// To é o email no canal email, o telefone no canal SMS e o identificador do usuário no canal push
+type LoginNotificationMessage struct {
+	TenantID       uuid.UUID                      `json:"tenant_id"`
+	UserID         uuid.UUID                      `json:"user_id"`
+	NotificationID uuid.UUID                      `json:"notification_id"`
+	Kind           model.LoginNotificationKind    `json:"kind"`
+	Channel        model.LoginNotificationChannel `json:"channel"`
+	Locale         string                         `json:"locale"`
+	To             string                         `json:"to"`
+	Subject        string                         `json:"subject,omitempty"`
+	Body           string                         `json:"body"`
+}
+
+// LoginNotificationSender entrega as mensagens das notificações de login num canal
+type LoginNotificationSender interface {
+	// Send entrega a mensagem ao usuário
+	Send(ctx context.Context, message *LoginNotificationMessage) error
+}
+
+// UpdateLoginNotificationSettingsRequest representa a alteração da configuração das notificações do tenant
+type UpdateLoginNotificationSettingsRequest struct {
+	TenantID         uuid.UUID                        `json:"tenant_id"`
+	Enabled          bool                             `json:"enabled"`
+	Channels         []model.LoginNotificationChannel `json:"channels"`
+	Market           string                           `json:"market,omitempty"`
+	DefaultLocale    string                           `json:"default_locale,omitempty"`
+	NotifyFirstLogin bool                             `json:"notify_first_login"`
+	UpdatedBy        uuid.UUID                        `json:"updated_by"`
+}
+
+// SaveLoginNotificationTemplateRequest representa a personalização de uma mensagem
+// O modelo substitui o existente da mesma mensagem, canal, mercado e idioma
+type SaveLoginNotificationTemplateRequest struct {
+	TenantID  uuid.UUID                      `json:"tenant_id"`
+	Kind      model.LoginNotificationKind    `json:"kind"`
+	Channel   model.LoginNotificationChannel `json:"channel"`
+	Market    string                         `json:"market,omitempty"`
+	Locale    string                         `json:"locale"`
+	Subject   string                         `json:"subject,omitempty"`
+	Body      string                         `json:"body"`
+	UpdatedBy uuid.UUID                      `json:"updated_by"`
+}
+
+// LoginNotMeResult representa o resultado da resposta "não fui eu"
+type LoginNotMeResult struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	SessionsEnded  int       `json:"sessions_ended"`
+	// CredentialResetExpiresAt é a validade do link de redefinição enviado ao usuário
+	CredentialResetExpiresAt time.Time `json:"credential_reset_expires_at"`
+}
+
+// LoginNotificationService define a interface de serviço para as notificações de logins suspeitos
+type LoginNotificationService interface {
+	LoginObserver
+
+	// GetSettings recupera a configuração das notificações do tenant
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.LoginNotificationSettings, error)
+
+	// UpdateSettings altera a configuração das notificações do tenant
+	UpdateSettings(ctx context.Context, req *UpdateLoginNotificationSettingsRequest) (*model.LoginNotificationSettings, error)
+
+	// ListTemplates recupera os modelos de mensagem personalizados do tenant
+	ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]*model.LoginNotificationTemplate, error)
+
+	// SaveTemplate valida e grava um modelo de mensagem do tenant
+	SaveTemplate(ctx context.Context, req *SaveLoginNotificationTemplateRequest) (*model.LoginNotificationTemplate, error)
+
+	// DeleteTemplate remove um modelo; a mensagem volta a usar o modelo padrão
+	DeleteTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) error
+
+	// RespondNotMe trata a resposta "não fui eu" de uma notificação: termina as sessões do
+	// usuário e envia-lhe o link de redefinição da credencial. O token é de uso único
+	RespondNotMe(ctx context.Context, tenantID uuid.UUID, token, ipAddress string) (*LoginNotMeResult, error)
+
+	// ListNotifications recupera as notificações mais recentes enviadas ao usuário
+	ListNotifications(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LoginNotification, error)
+}
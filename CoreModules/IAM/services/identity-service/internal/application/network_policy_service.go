@@ -0,0 +1,80 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das políticas de rede
+var (
+	ErrNetworkPolicyNotFound  = model.ErrNetworkPolicyNotFound
+	ErrInvalidNetworkPolicy   = model.ErrInvalidNetworkPolicy
+	ErrNetworkPolicyConflict  = model.ErrNetworkPolicyConflict
+	ErrBreakGlassNotFound     = model.ErrBreakGlassNotFound
+	ErrInvalidBreakGlass      = model.ErrInvalidBreakGlass
+	ErrBreakGlassAuditFailure = model.ErrBreakGlassAuditFailure
+)
+
+// SaveNetworkPolicyRequest representa a criação ou a alteração de uma política de rede
+// Na alteração, PolicyID identifica a política e todos os campos são substituídos
+type SaveNetworkPolicyRequest struct {
+	TenantID uuid.UUID                 `json:"tenant_id"`
+	PolicyID uuid.UUID                 `json:"policy_id,omitempty"`
+	Name     string                    `json:"name"`
+	Scope    model.NetworkPolicyScope  `json:"scope"`
+	Action   model.NetworkPolicyAction `json:"action"`
+	RoleCode string                    `json:"role_code,omitempty"`
+	CIDRs    []string                  `json:"cidrs"`
+	Enabled  bool                      `json:"enabled"`
+	ActorID  uuid.UUID                 `json:"actor_id"`
+}
+
+// ActivateBreakGlassRequest representa a ativação de um acesso de emergência
+// A justificação é obrigatória e fica registada com cada uso
+type ActivateBreakGlassRequest struct {
+	TenantID      uuid.UUID     `json:"tenant_id"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Justification string        `json:"justification"`
+	TicketRef     string        `json:"ticket_ref,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	GrantedBy     uuid.UUID     `json:"granted_by"`
+}
+
+// NetworkPolicyService define a interface de serviço para as políticas de rede dos tenants
+type NetworkPolicyService interface {
+	// ListPolicies recupera as políticas de rede do tenant
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.NetworkPolicy, error)
+
+	// GetPolicy recupera uma política de rede do tenant
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.NetworkPolicy, error)
+
+	// CreatePolicy cria uma política de rede
+	CreatePolicy(ctx context.Context, req *SaveNetworkPolicyRequest) (*model.NetworkPolicy, error)
+
+	// UpdatePolicy altera uma política de rede
+	UpdatePolicy(ctx context.Context, req *SaveNetworkPolicyRequest) (*model.NetworkPolicy, error)
+
+	// DeletePolicy remove uma política de rede
+	DeletePolicy(ctx context.Context, tenantID, policyID, actorID uuid.UUID) error
+
+	// Evaluate decide se o pedido é permitido pelas políticas de rede do tenant
+	// Um pedido bloqueado é permitido pelo acesso de emergência em vigor do usuário,
+	// desde que o uso fique auditado
+	Evaluate(ctx context.Context, req *model.NetworkAccessRequest) (*model.NetworkDecision, error)
+
+	// ActivateBreakGlass ativa um acesso de emergência temporário para um usuário
+	ActivateBreakGlass(ctx context.Context, req *ActivateBreakGlassRequest) (*model.BreakGlassOverride, error)
+
+	// RevokeBreakGlass revoga um acesso de emergência antes de expirar
+	RevokeBreakGlass(ctx context.Context, tenantID, overrideID, actorID uuid.UUID) error
+
+	// ListBreakGlass recupera os acessos de emergência do tenant; activeOnly limita aos que estão em vigor
+	ListBreakGlass(ctx context.Context, tenantID uuid.UUID, activeOnly bool) ([]*model.BreakGlassOverride, error)
+
+	// ListBreakGlassUses recupera o registo de auditoria de um acesso de emergência
+	ListBreakGlassUses(ctx context.Context, tenantID, overrideID uuid.UUID, limit int) ([]*model.BreakGlassUse, error)
+}
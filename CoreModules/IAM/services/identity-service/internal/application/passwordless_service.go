@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da autenticação sem senha
+var (
+	ErrPasswordlessDisabled         = model.ErrPasswordlessDisabled
+	ErrPasswordlessNotAllowed       = model.ErrPasswordlessNotAllowed
+	ErrPasswordlessMethodNotAllowed = model.ErrPasswordlessMethodNotAllowed
+	ErrInvalidPasswordlessSettings  = model.ErrInvalidPasswordlessSettings
+	ErrPasswordlessRateLimited      = model.ErrPasswordlessRateLimited
+	ErrInvalidPasswordlessToken     = model.ErrInvalidPasswordlessToken
+	ErrPasswordlessDeviceRequired   = model.ErrPasswordlessDeviceRequired
+)
+
+// PasswordlessSender entrega os links mágicos e os códigos aos usuários
+// O serviço de autenticação sem senha usa-o para ficar independente do canal de envio
+type PasswordlessSender interface {
+	// SendMagicLink envia o link mágico para o email do usuário
+	SendMagicLink(ctx context.Context, tenantID uuid.UUID, email, link string, expiresAt time.Time) error
+
+	// SendOTP envia o código de uso único para o email do usuário
+	SendOTP(ctx context.Context, tenantID uuid.UUID, email, code string, expiresAt time.Time) error
+}
+
+// UpdatePasswordlessSettingsRequest representa a alteração da configuração sem senha do tenant
+type UpdatePasswordlessSettingsRequest struct {
+	TenantID        uuid.UUID                       `json:"tenant_id"`
+	Enabled         bool                            `json:"enabled"`
+	Methods         []model.PasswordlessMethod      `json:"methods"`
+	DeviceBinding   model.PasswordlessDeviceBinding `json:"device_binding"`
+	Market          string                          `json:"market"`
+	FinancialTenant bool                            `json:"financial_tenant"`
+	UpdatedBy       uuid.UUID                       `json:"updated_by"`
+}
+
+// StartPasswordlessLoginRequest representa o pedido de um link mágico ou de um código
+// DeviceID identifica o dispositivo que pede o desafio, para a vinculação ao dispositivo
+type StartPasswordlessLoginRequest struct {
+	TenantID  uuid.UUID                `json:"tenant_id"`
+	Email     string                   `json:"email"`
+	Method    model.PasswordlessMethod `json:"method"`
+	DeviceID  string                   `json:"device_id,omitempty"`
+	IPAddress string                   `json:"ip_address,omitempty"`
+	UserAgent string                   `json:"user_agent,omitempty"`
+}
+
+// PasswordlessChallengeIssued representa um desafio emitido
+// A resposta é a mesma quer o email tenha conta no tenant quer não
+type PasswordlessChallengeIssued struct {
+	ChallengeID uuid.UUID                `json:"challenge_id"`
+	Method      model.PasswordlessMethod `json:"method"`
+	ExpiresAt   time.Time                `json:"expires_at"`
+}
+
+// VerifyPasswordlessRequest representa a conclusão de um login sem senha
+// O link mágico é verificado pelo Token; o código pelo ChallengeID e pelo Code.
+// O endereço, o agente e o país identificam o contexto do login para as notificações de login
+type VerifyPasswordlessRequest struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Token       string    `json:"token,omitempty"`
+	ChallengeID uuid.UUID `json:"challenge_id,omitempty"`
+	Code        string    `json:"code,omitempty"`
+	DeviceID    string    `json:"device_id,omitempty"`
+	IPAddress   string    `json:"ip_address,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	Country     string    `json:"country,omitempty"`
+}
+
+// PasswordlessLoginResult representa um login sem senha concluído
+type PasswordlessLoginResult struct {
+	User   *model.User              `json:"user"`
+	Method model.PasswordlessMethod `json:"method"`
+	// DeviceBound indica que o desafio foi concluído no dispositivo que o pediu
+	DeviceBound     bool      `json:"device_bound"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+	// PendingDocuments lista os documentos legais obrigatórios por aceitar; o login não é
+	// concluído até o usuário os aceitar
+	PendingDocuments []*model.LegalDocument `json:"pending_documents,omitempty"`
+}
+
+// PasswordlessService define a interface de serviço para a autenticação sem senha
+type PasswordlessService interface {
+	// GetSettings recupera a configuração sem senha do tenant
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.PasswordlessSettings, error)
+
+	// UpdateSettings altera a configuração sem senha do tenant, respeitando a política do mercado
+	UpdateSettings(ctx context.Context, req *UpdatePasswordlessSettingsRequest) (*model.PasswordlessSettings, error)
+
+	// StartLogin emite um link mágico ou um código e envia-o para o email indicado
+	StartLogin(ctx context.Context, req *StartPasswordlessLoginRequest) (*PasswordlessChallengeIssued, error)
+
+	// VerifyMagicLink conclui o login com o token do link mágico
+	VerifyMagicLink(ctx context.Context, req *VerifyPasswordlessRequest) (*PasswordlessLoginResult, error)
+
+	// VerifyOTP conclui o login com o código enviado por email
+	VerifyOTP(ctx context.Context, req *VerifyPasswordlessRequest) (*PasswordlessLoginResult, error)
+}
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos dos limites de permissões
+var (
+	ErrPermissionBoundaryNotFound        = model.ErrPermissionBoundaryNotFound
+	ErrPermissionBoundarySubjectNotFound = model.ErrPermissionBoundarySubjectNotFound
+	ErrPermissionBoundaryConflict        = model.ErrPermissionBoundaryConflict
+	ErrInvalidPermissionBoundary         = model.ErrInvalidPermissionBoundary
+	ErrPermissionBoundaryViolation       = model.ErrPermissionBoundaryViolation
+)
+
+// PermissionBoundaryEnforcer aplica os limites de permissões na avaliação do PDP
+type PermissionBoundaryEnforcer interface {
+	// ResolvePolicyBoundaries retorna os limites do usuário e das suas funções, para as políticas
+	// Retorna nil quando o tenant não tem limites
+	ResolvePolicyBoundaries(ctx context.Context, tenantID, userID uuid.UUID, roles []string) (*model.PermissionBoundaryPolicyInput, error)
+
+	// RecordViolation regista a violação como incidente de segurança de severidade alta e publica o evento
+	RecordViolation(ctx context.Context, violation *model.PermissionBoundaryViolation) (*model.SecurityIncident, error)
+}
+
+// SavePermissionBoundaryRequest representa a criação ou a alteração de um limite de permissões
+// Na alteração, BoundaryID identifica o limite; o titular não pode ser alterado
+type SavePermissionBoundaryRequest struct {
+	TenantID    uuid.UUID                           `json:"tenant_id"`
+	BoundaryID  uuid.UUID                           `json:"boundary_id,omitempty"`
+	SubjectType model.PermissionBoundarySubjectType `json:"subject_type"`
+	SubjectID   uuid.UUID                           `json:"subject_id"`
+	Name        string                              `json:"name"`
+	Description string                              `json:"description,omitempty"`
+	Permissions []string                            `json:"permissions"`
+	ActorID     uuid.UUID                           `json:"actor_id"`
+}
+
+// PermissionBoundaryCheck descreve uma concessão de permissões a verificar contra os limites
+type PermissionBoundaryCheck struct {
+	TenantID uuid.UUID
+	// Administrador que faz a concessão, verificado contra o seu limite de administrador delegado
+	ActorID   uuid.UUID
+	Operation model.PermissionBoundaryOperation
+	// Função alvo da operação
+	RoleID   uuid.UUID
+	RoleCode string
+	// Funções que passam a deter as permissões, verificadas contra os seus limites
+	// (a função alvo e as descendentes, que herdam as permissões)
+	BoundedRoleIDs []uuid.UUID
+	// Usuários ou permissões afetados pela operação, registados no incidente
+	SubjectIDs []uuid.UUID
+	// Códigos das permissões concedidas
+	Permissions []string
+}
+
+// PermissionBoundaryService define a interface de serviço para os limites de permissões
+// Os limites do tenant ficam em cache para a avaliação do PDP; as verificações das atribuições
+// usam sempre os limites gravados
+type PermissionBoundaryService interface {
+	PermissionBoundaryEnforcer
+
+	// ListBoundaries recupera os limites do tenant
+	ListBoundaries(ctx context.Context, tenantID uuid.UUID) ([]*model.PermissionBoundary, error)
+
+	// GetBoundary recupera um limite do tenant
+	GetBoundary(ctx context.Context, tenantID, boundaryID uuid.UUID) (*model.PermissionBoundary, error)
+
+	// CreateBoundary cria o limite de uma função ou de um administrador delegado
+	// As permissões já atribuídas não são revogadas; o PDP deixa de as conceder fora do limite
+	CreateBoundary(ctx context.Context, req *SavePermissionBoundaryRequest) (*model.PermissionBoundary, error)
+
+	// UpdateBoundary altera o nome, a descrição e os padrões de um limite
+	UpdateBoundary(ctx context.Context, req *SavePermissionBoundaryRequest) (*model.PermissionBoundary, error)
+
+	// DeleteBoundary remove um limite
+	DeleteBoundary(ctx context.Context, tenantID, boundaryID, actorID uuid.UUID) error
+
+	// CheckGrant verifica a concessão contra os limites das funções e do administrador
+	// Uma concessão fora dos limites é registada como violação e retorna ErrPermissionBoundaryViolation
+	CheckGrant(ctx context.Context, check *PermissionBoundaryCheck) error
+}
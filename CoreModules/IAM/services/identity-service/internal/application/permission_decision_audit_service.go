@@ -0,0 +1,27 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da auditoria de decisões de autorização
+var (
+	ErrInvalidPermissionDecisionFilter = model.ErrInvalidPermissionDecisionFilter
+)
+
+// PermissionDecisionAuditService define a interface de serviço para a auditoria das decisões do PDP
+type PermissionDecisionAuditService interface {
+	// Record regista uma decisão de autorização sem bloquear o pedido
+	// As negações são sempre registadas; as permissões são amostradas conforme a configuração
+	Record(ctx context.Context, decision *model.PermissionDecision)
+
+	// Flush grava no armazenamento de auditoria as decisões ainda em memória
+	Flush(ctx context.Context) error
+
+	// ListDecisions recupera as decisões do tenant que satisfazem o filtro, da mais recente para a mais antiga
+	ListDecisions(ctx context.Context, tenantID uuid.UUID, filter model.PermissionDecisionFilter) ([]*model.PermissionDecision, error)
+}
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das campanhas de recertificação
+var (
+	ErrRecertificationCampaignNotFound = model.ErrRecertificationCampaignNotFound
+	ErrInvalidRecertificationCampaign  = model.ErrInvalidRecertificationCampaign
+	ErrRecertificationCampaignState    = model.ErrRecertificationCampaignState
+	ErrRecertificationEmptyScope       = model.ErrRecertificationEmptyScope
+	ErrRecertificationItemNotFound     = model.ErrRecertificationItemNotFound
+	ErrInvalidRecertificationDecision  = model.ErrInvalidRecertificationDecision
+	ErrRecertificationItemDecided      = model.ErrRecertificationItemDecided
+	ErrRecertificationNotReviewer      = model.ErrRecertificationNotReviewer
+	ErrRecertificationSelfReview       = model.ErrRecertificationSelfReview
+)
+
+// CreateRecertificationCampaignRequest representa a abertura de uma campanha de recertificação
+// Sem UserIDs, a campanha abrange todos os titulares das funções; sem EscalateAt, o escalamento
+// ocorre na fração do prazo configurada no serviço
+type CreateRecertificationCampaignRequest struct {
+	TenantID             uuid.UUID   `json:"tenant_id"`
+	Name                 string      `json:"name"`
+	Description          string      `json:"description,omitempty"`
+	RoleIDs              []uuid.UUID `json:"role_ids"`
+	UserIDs              []uuid.UUID `json:"user_ids,omitempty"`
+	DefaultReviewerID    uuid.UUID   `json:"default_reviewer_id"`
+	EscalationReviewerID uuid.UUID   `json:"escalation_reviewer_id"`
+	Deadline             time.Time   `json:"deadline"`
+	EscalateAt           *time.Time  `json:"escalate_at,omitempty"`
+	ActorID              uuid.UUID   `json:"actor_id"`
+}
+
+// RecertificationDecisionRequest representa a decisão de um revisor sobre um item
+type RecertificationDecisionRequest struct {
+	TenantID   uuid.UUID                     `json:"tenant_id"`
+	ItemID     uuid.UUID                     `json:"item_id"`
+	ReviewerID uuid.UUID                     `json:"reviewer_id"`
+	Decision   model.RecertificationDecision `json:"decision"`
+	Comment    string                        `json:"comment,omitempty"`
+}
+
+// RecertificationSweepResult resume uma execução dos escalamentos e revogações automáticas
+type RecertificationSweepResult struct {
+	StartedAt          time.Time `json:"started_at"`
+	Escalated          int       `json:"escalated"`
+	AutoRevoked        int       `json:"auto_revoked"`
+	CampaignsCompleted int       `json:"campaigns_completed"`
+	RevocationsRetried int       `json:"revocations_retried"`
+	Failed             int       `json:"failed"`
+}
+
+// RecertificationService define a interface de serviço para as campanhas de recertificação de acessos
+type RecertificationService interface {
+	// CreateCampaign abre uma campanha, gera um item por atribuição no âmbito e notifica os revisores
+	CreateCampaign(ctx context.Context, req *CreateRecertificationCampaignRequest) (*model.RecertificationCampaign, error)
+
+	// GetCampaign recupera uma campanha do tenant
+	GetCampaign(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationCampaign, error)
+
+	// ListCampaigns recupera as campanhas do tenant; status limita a um estado
+	ListCampaigns(ctx context.Context, tenantID uuid.UUID, status model.RecertificationCampaignStatus) ([]*model.RecertificationCampaign, error)
+
+	// CancelCampaign cancela uma campanha ativa; as decisões já tomadas mantêm-se
+	CancelCampaign(ctx context.Context, tenantID, campaignID, actorID uuid.UUID, reason string) (*model.RecertificationCampaign, error)
+
+	// ListItems recupera os itens de uma campanha que satisfazem o filtro
+	ListItems(ctx context.Context, tenantID, campaignID uuid.UUID, filter model.RecertificationItemFilter) ([]*model.RecertificationItem, error)
+
+	// ListPendingReviews recupera os itens por decidir atribuídos ao revisor
+	ListPendingReviews(ctx context.Context, tenantID, reviewerID uuid.UUID) ([]*model.RecertificationItem, error)
+
+	// Decide regista a decisão do revisor; a revogação retira de imediato a atribuição da função
+	// e a última decisão conclui a campanha
+	Decide(ctx context.Context, req *RecertificationDecisionRequest) (*model.RecertificationItem, error)
+
+	// GetAttestation gera o relatório de atestação de uma campanha concluída
+	GetAttestation(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationAttestation, error)
+
+	// RunScheduledEscalations escala os itens por decidir na data de escalamento, revoga os itens
+	// não decididos no prazo, conclui as campanhas e repete as revogações que falharam
+	RunScheduledEscalations(ctx context.Context) (*RecertificationSweepResult, error)
+}
@@ -0,0 +1,32 @@
+package application
+
+import (
+	"context"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das subscriptions das alterações de funções
+var (
+	ErrRoleChangeSubscriptionLimit = model.ErrRoleChangeSubscriptionLimit
+	ErrRoleChangeFeedClosed        = model.ErrRoleChangeFeedClosed
+)
+
+// RoleChangeFeed define a interface do fluxo em tempo real das alterações das funções
+// O fluxo recebe os eventos de domínio das funções do barramento de eventos e entrega-os,
+// filtrados, às subscriptions abertas; a autorização de cada subscription é feita por quem a abre
+type RoleChangeFeed interface {
+	// Subscribe abre uma subscription com o filtro indicado, contada nas quotas do subscritor
+	// O canal é fechado quando o contexto termina, quando a subscription não consome as
+	// notificações ao ritmo a que chegam ou quando o fluxo é encerrado; as notificações
+	// são partilhadas entre subscriptions e não devem ser alteradas
+	Subscribe(ctx context.Context, subscriber model.RoleChangeSubscriber, filter model.RoleChangeFilter) (<-chan *model.RoleChangeNotification, error)
+
+	// HandleEvent entrega às subscriptions a alteração descrita por um evento de domínio
+	// Os eventos que não dizem respeito às funções são ignorados
+	HandleEvent(ctx context.Context, evt event.Event) error
+
+	// ActiveSubscriptions retorna o número de subscriptions abertas
+	ActiveSubscriptions() int
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos do histórico de funções
+var (
+	ErrRoleHistoryNotFound = model.ErrRoleHistoryNotFound
+	ErrInvalidHistoryRange = errors.New("intervalo de datas inválido para o histórico da função")
+)
+
+// RoleHistoryService define a interface de serviço para o histórico event-sourced de funções
+type RoleHistoryService interface {
+	// Record registra um evento no histórico da função, atribuindo a próxima versão
+	// e o instante de ocorrência
+	Record(ctx context.Context, evt *model.RoleHistoryEvent) error
+
+	// GetRoleHistory recupera todos os eventos da função em ordem de versão
+	GetRoleHistory(ctx context.Context, tenantID, roleID uuid.UUID) ([]*model.RoleHistoryEvent, error)
+
+	// GetRoleAt reconstrói o estado da função no instante indicado
+	GetRoleAt(ctx context.Context, tenantID, roleID uuid.UUID, at time.Time) (*model.RoleState, error)
+
+	// DiffRole compara o estado da função entre dois instantes
+	DiffRole(ctx context.Context, tenantID, roleID uuid.UUID, from, to time.Time) (*model.RoleStateDiff, error)
+}
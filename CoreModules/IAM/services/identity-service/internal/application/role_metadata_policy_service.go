@@ -0,0 +1,26 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das políticas dos metadados das funções
+var (
+	ErrRoleMetadataWriteDenied = model.ErrRoleMetadataWriteDenied
+)
+
+// RoleMetadataPolicyService define a interface de serviço para as políticas de acesso aos metadados das funções
+// As políticas são geridas no catálogo de permissões: a permissão "roles:metadata.<chave>:read" restringe
+// a leitura da chave e "roles:metadata.<chave>:write" a sua alteração; as alterações do catálogo
+// são aplicadas quando expira o cache das políticas do tenant
+type RoleMetadataPolicyService interface {
+	// ListPolicies lista as chaves protegidas dos metadados do tenant e as permissões que exigem
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleMetadataPolicy, error)
+
+	// Access calcula as chaves protegidas que o usuário pode ler e alterar
+	Access(ctx context.Context, tenantID, userID uuid.UUID) (*model.RoleMetadataAccess, error)
+}
@@ -0,0 +1,67 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da mineração de funções
+var (
+	ErrRoleSuggestionNotFound   = model.ErrRoleSuggestionNotFound
+	ErrRoleSuggestionNotPending = model.ErrRoleSuggestionNotPending
+	ErrInvalidRoleSuggestion    = model.ErrInvalidRoleSuggestion
+)
+
+// AcceptRoleSuggestionRequest representa a aceitação de uma sugestão, criando a função no tenant
+type AcceptRoleSuggestionRequest struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	SuggestionID uuid.UUID `json:"suggestion_id"`
+	RoleCode     string    `json:"role_code"`
+	RoleName     string    `json:"role_name"`
+	Description  string    `json:"description,omitempty"`
+	// AssignUsers atribui a nova função aos usuários que detêm as permissões da sugestão
+	// As permissões diretas desses usuários mantêm-se até à próxima revisão de acessos
+	AssignUsers bool      `json:"assign_users"`
+	AcceptedBy  uuid.UUID `json:"accepted_by"`
+}
+
+// RoleSuggestionAcceptance representa o resultado da aceitação de uma sugestão
+type RoleSuggestionAcceptance struct {
+	Suggestion    *model.RoleSuggestion `json:"suggestion"`
+	Role          *model.Role           `json:"role"`
+	AssignedUsers int                   `json:"assigned_users"`
+}
+
+// RoleMiningRunResult resume uma passagem da mineração de funções por todos os tenants
+type RoleMiningRunResult struct {
+	StartedAt   time.Time `json:"started_at"`
+	Tenants     int       `json:"tenants"`
+	Suggestions int       `json:"suggestions"`
+	Failed      int       `json:"failed"`
+}
+
+// RoleMiningService define a interface de serviço para as sugestões de função mineradas das permissões diretas
+type RoleMiningService interface {
+	// MineTenant recalcula as sugestões pendentes do tenant a partir das permissões diretas atuais
+	// Conjuntos de permissões já aceites ou descartados não voltam a ser sugeridos
+	MineTenant(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleSuggestion, error)
+
+	// MineAll recalcula as sugestões de todos os tenants com permissões diretas
+	MineAll(ctx context.Context) (*RoleMiningRunResult, error)
+
+	// ListSuggestions recupera as sugestões do tenant no estado indicado (todas quando vazio)
+	ListSuggestions(ctx context.Context, tenantID uuid.UUID, status string) ([]*model.RoleSuggestion, error)
+
+	// GetSuggestion recupera uma sugestão do tenant
+	GetSuggestion(ctx context.Context, tenantID, suggestionID uuid.UUID) (*model.RoleSuggestion, error)
+
+	// AcceptSuggestion cria a função com as permissões da sugestão e marca-a como aceite
+	AcceptSuggestion(ctx context.Context, req *AcceptRoleSuggestionRequest) (*RoleSuggestionAcceptance, error)
+
+	// DismissSuggestion descarta a sugestão
+	DismissSuggestion(ctx context.Context, tenantID, suggestionID, dismissedBy uuid.UUID) (*model.RoleSuggestion, error)
+}
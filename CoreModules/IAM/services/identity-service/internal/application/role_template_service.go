@@ -0,0 +1,93 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos dos modelos de função
+var (
+	ErrRoleTemplateNotFound            = model.ErrRoleTemplateNotFound
+	ErrInvalidRoleTemplate             = model.ErrInvalidRoleTemplate
+	ErrRoleTemplateCodeAlreadyExists   = model.ErrRoleTemplateCodeAlreadyExists
+	ErrRoleTemplateInactive            = model.ErrRoleTemplateInactive
+	ErrRoleTemplateNotAvailable        = model.ErrRoleTemplateNotAvailable
+	ErrRoleTemplateConflict            = model.ErrRoleTemplateConflict
+	ErrRoleTemplateInstanceNotFound    = model.ErrRoleTemplateInstanceNotFound
+	ErrRoleTemplateAlreadyInstantiated = model.ErrRoleTemplateAlreadyInstantiated
+	ErrRoleTemplatePermissionsUnmapped = model.ErrRoleTemplatePermissionsUnmapped
+)
+
+// CreateRoleTemplateRequest representa a publicação de um modelo no catálogo global
+type CreateRoleTemplateRequest struct {
+	Code            string                 `json:"code"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	Market          string                 `json:"market,omitempty"`
+	PermissionCodes []string               `json:"permission_codes"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	CreatedBy       uuid.UUID              `json:"created_by"`
+}
+
+// UpdateRoleTemplateRequest representa a revisão de um modelo
+// PermissionCodes nulo mantém as permissões; a alteração das permissões avança a versão
+type UpdateRoleTemplateRequest struct {
+	TemplateID      uuid.UUID `json:"template_id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	PermissionCodes []string  `json:"permission_codes,omitempty"`
+	IsActive        *bool     `json:"is_active,omitempty"`
+	UpdatedBy       uuid.UUID `json:"updated_by"`
+}
+
+// InstantiateRoleTemplateRequest representa a criação de uma função do tenant a partir de um modelo
+type InstantiateRoleTemplateRequest struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	// Market do tenant; quando informado, o modelo deve estar disponível nele
+	Market string `json:"market,omitempty"`
+	// RoleCode e RoleName substituem o código e o nome do modelo quando informados
+	RoleCode string `json:"role_code,omitempty"`
+	RoleName string `json:"role_name,omitempty"`
+	// PermissionMapping associa códigos do modelo a códigos de permissão diferentes no tenant
+	PermissionMapping map[string]string `json:"permission_mapping,omitempty"`
+	// AllowPartial instancia a função mesmo que alguns códigos não tenham correspondência
+	AllowPartial   bool      `json:"allow_partial"`
+	InstantiatedBy uuid.UUID `json:"instantiated_by"`
+}
+
+// RoleTemplateInstantiation representa o resultado da instanciação de um modelo
+type RoleTemplateInstantiation struct {
+	Role     *model.Role                 `json:"role"`
+	Instance *model.RoleTemplateInstance `json:"instance"`
+}
+
+// RoleTemplateService define a interface de serviço para a biblioteca de modelos de função
+type RoleTemplateService interface {
+	// CreateTemplate publica um novo modelo no catálogo global
+	CreateTemplate(ctx context.Context, req *CreateRoleTemplateRequest) (*model.RoleTemplate, error)
+
+	// UpdateTemplate revê um modelo do catálogo
+	UpdateTemplate(ctx context.Context, req *UpdateRoleTemplateRequest) (*model.RoleTemplate, error)
+
+	// GetTemplate recupera um modelo pelo ID
+	GetTemplate(ctx context.Context, templateID uuid.UUID) (*model.RoleTemplate, error)
+
+	// ListTemplates recupera os modelos do catálogo que satisfazem o filtro
+	ListTemplates(ctx context.Context, filter model.RoleTemplateFilter) ([]*model.RoleTemplate, error)
+
+	// Instantiate cria a função no tenant e atribui-lhe as permissões correspondentes às do modelo
+	Instantiate(ctx context.Context, req *InstantiateRoleTemplateRequest) (*RoleTemplateInstantiation, error)
+
+	// ListInstances recupera as funções do tenant instanciadas a partir de modelos
+	ListInstances(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateInstance, error)
+
+	// DetectDrift compara uma função instanciada com a versão atual do seu modelo
+	DetectDrift(ctx context.Context, tenantID, roleID uuid.UUID) (*model.RoleTemplateDrift, error)
+
+	// DetectTenantDrift compara todas as funções instanciadas do tenant com os seus modelos
+	DetectTenantDrift(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateDrift, error)
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da federação SAML
+var (
+	ErrSAMLProviderNotFound      = model.ErrSAMLProviderNotFound
+	ErrInvalidSAMLProvider       = model.ErrInvalidSAMLProvider
+	ErrSAMLProviderAlreadyExists = model.ErrSAMLProviderAlreadyExists
+	ErrSAMLProviderInactive      = model.ErrSAMLProviderInactive
+	ErrInvalidSAMLMetadata       = model.ErrInvalidSAMLMetadata
+	ErrInvalidSAMLResponse       = model.ErrInvalidSAMLResponse
+	ErrSAMLRequestNotFound       = model.ErrSAMLRequestNotFound
+	ErrInvalidSAMLReturnTo       = model.ErrInvalidSAMLReturnTo
+	ErrSAMLProvisioningDisabled  = model.ErrSAMLProvisioningDisabled
+	ErrSAMLAccountConflict       = model.ErrSAMLAccountConflict
+	ErrFederatedUserNotAllowed   = model.ErrFederatedUserNotAllowed
+)
+
+// SAMLServiceProvider implementa o protocolo SAML 2.0 do lado do fornecedor de serviço
+// O serviço de federação usa-o para gerar metadados e pedidos e para validar respostas,
+// mantendo a lógica de aplicação independente da biblioteca SAML
+type SAMLServiceProvider interface {
+	// Metadata gera os metadados do SP para o fornecedor de identidade indicado
+	Metadata(provider *model.SAMLIdentityProvider) ([]byte, error)
+
+	// AuthnRequest gera um pedido de autenticação (binding HTTP-Redirect) e retorna
+	// o seu ID e o URL para o qual o navegador deve ser redirecionado
+	AuthnRequest(provider *model.SAMLIdentityProvider, relayState string) (requestID, redirectURL string, err error)
+
+	// ParseResponse valida a assinatura, a audiência, o destino, a validade e o InResponseTo
+	// da resposta (codificada em base64, binding HTTP-POST) e retorna a asserção
+	// Retorna model.ErrInvalidSAMLResponse quando a resposta não é aceite
+	ParseResponse(provider *model.SAMLIdentityProvider, samlResponse, requestID string) (*model.SAMLAssertion, error)
+
+	// ParseIdPMetadata extrai o entity ID, o URL de SSO, os certificados de assinatura e
+	// o formato de NameID dos metadados publicados pelo IdP
+	// Retorna model.ErrInvalidSAMLMetadata quando os metadados não descrevem um IdP utilizável
+	ParseIdPMetadata(metadata []byte) (*model.SAMLIdentityProvider, error)
+}
+
+// RegisterSAMLProviderRequest representa o registo de um fornecedor de identidade no tenant
+// Quando MetadataXML é informado, os dados do IdP são lidos dos metadados e prevalecem
+// sobre EntityID, SSOURL, SigningCertificates e NameIDFormat
+type RegisterSAMLProviderRequest struct {
+	TenantID            uuid.UUID                   `json:"tenant_id"`
+	Name                string                      `json:"name"`
+	MetadataXML         string                      `json:"metadata_xml,omitempty"`
+	EntityID            string                      `json:"entity_id,omitempty"`
+	SSOURL              string                      `json:"sso_url,omitempty"`
+	SigningCertificates []string                    `json:"signing_certificates,omitempty"`
+	NameIDFormat        string                      `json:"name_id_format,omitempty"`
+	AttributeMapping    model.SAMLAttributeMapping  `json:"attribute_mapping"`
+	RoleMappingRules    []model.SAMLRoleMappingRule `json:"role_mapping_rules,omitempty"`
+	JITProvisioning     bool                        `json:"jit_provisioning"`
+	CreatedBy           uuid.UUID                   `json:"created_by"`
+}
+
+// UpdateSAMLProviderRequest representa a alteração de um fornecedor de identidade
+// Os campos nulos mantêm o valor atual; MetadataXML substitui os dados do IdP
+type UpdateSAMLProviderRequest struct {
+	TenantID         uuid.UUID                   `json:"tenant_id"`
+	ProviderID       uuid.UUID                   `json:"provider_id"`
+	Name             string                      `json:"name,omitempty"`
+	MetadataXML      string                      `json:"metadata_xml,omitempty"`
+	AttributeMapping *model.SAMLAttributeMapping `json:"attribute_mapping,omitempty"`
+	RoleMappingRules []model.SAMLRoleMappingRule `json:"role_mapping_rules,omitempty"`
+	JITProvisioning  *bool                       `json:"jit_provisioning,omitempty"`
+	IsActive         *bool                       `json:"is_active,omitempty"`
+	UpdatedBy        uuid.UUID                   `json:"updated_by"`
+}
+
+// SAMLLoginRedirect representa o início de um login federado
+type SAMLLoginRedirect struct {
+	RedirectURL string `json:"redirect_url"`
+	RequestID   string `json:"request_id"`
+	RelayState  string `json:"relay_state"`
+}
+
+// SAMLLoginResult representa um login federado concluído ou à espera da ligação de contas
+type SAMLLoginResult struct {
+	User     *model.User              `json:"user"`
+	Identity *model.FederatedIdentity `json:"identity"`
+	// Provisioned indica que o usuário foi criado neste login
+	Provisioned bool `json:"provisioned"`
+	// RolesGranted e RolesRevoked descrevem a sincronização das funções mapeadas
+	RolesGranted []uuid.UUID `json:"roles_granted"`
+	RolesRevoked []uuid.UUID `json:"roles_revoked"`
+	ReturnTo     string      `json:"return_to,omitempty"`
+	SessionIndex string      `json:"session_index,omitempty"`
+	// LinkCandidate indica que o NameID corresponde a uma conta local com o mesmo email verificado;
+	// o login não é concluído e o usuário tem de ligar as contas com a sua autenticação local
+	LinkCandidate *model.AccountLinkCandidate `json:"link_candidate,omitempty"`
+	// PendingDocuments lista os documentos legais obrigatórios por aceitar; o login não é
+	// concluído até o usuário os aceitar
+	PendingDocuments []*model.LegalDocument `json:"pending_documents,omitempty"`
+}
+
+// SAMLFederationService define a interface de serviço para a federação com IdP SAML 2.0
+type SAMLFederationService interface {
+	// RegisterProvider regista um fornecedor de identidade no tenant
+	RegisterProvider(ctx context.Context, req *RegisterSAMLProviderRequest) (*model.SAMLIdentityProvider, error)
+
+	// UpdateProvider altera um fornecedor de identidade do tenant
+	UpdateProvider(ctx context.Context, req *UpdateSAMLProviderRequest) (*model.SAMLIdentityProvider, error)
+
+	// GetProvider recupera um fornecedor de identidade do tenant
+	GetProvider(ctx context.Context, tenantID, providerID uuid.UUID) (*model.SAMLIdentityProvider, error)
+
+	// ListProviders recupera os fornecedores de identidade do tenant
+	ListProviders(ctx context.Context, tenantID uuid.UUID) ([]*model.SAMLIdentityProvider, error)
+
+	// ServiceProviderMetadata gera os metadados do SP a entregar ao administrador do IdP
+	ServiceProviderMetadata(ctx context.Context, providerID uuid.UUID) ([]byte, error)
+
+	// StartLogin emite um pedido de autenticação para o IdP e regista-o para validar a resposta
+	StartLogin(ctx context.Context, providerID uuid.UUID, returnTo string) (*SAMLLoginRedirect, error)
+
+	// CompleteLogin valida a resposta do IdP, provisiona o usuário no primeiro login quando
+	// permitido e sincroniza as funções atribuídas pelas regras de mapeamento
+	CompleteLogin(ctx context.Context, providerID uuid.UUID, samlResponse, relayState string) (*SAMLLoginResult, error)
+}
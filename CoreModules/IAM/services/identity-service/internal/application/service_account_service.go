@@ -0,0 +1,140 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das contas de serviço
+var (
+	ErrServiceAccountNotFound              = model.ErrServiceAccountNotFound
+	ErrServiceAccountConflict              = model.ErrServiceAccountConflict
+	ErrInvalidServiceAccount               = model.ErrInvalidServiceAccount
+	ErrServiceAccountRoleNotFound          = model.ErrServiceAccountRoleNotFound
+	ErrServiceAccountRoleNotBound          = model.ErrServiceAccountRoleNotBound
+	ErrServiceAccountRoleLimit             = model.ErrServiceAccountRoleLimit
+	ErrInvalidWorkloadIdentity             = model.ErrInvalidWorkloadIdentity
+	ErrWorkloadIdentityConflict            = model.ErrWorkloadIdentityConflict
+	ErrServiceAccountCredentialNotFound    = model.ErrServiceAccountCredentialNotFound
+	ErrServiceAccountCredentialConflict    = model.ErrServiceAccountCredentialConflict
+	ErrInvalidServiceAccountCredential     = model.ErrInvalidServiceAccountCredential
+	ErrServiceAccountCredentialLimit       = model.ErrServiceAccountCredentialLimit
+	ErrServiceAccountAuthenticationFailed  = model.ErrServiceAccountAuthenticationFailed
+	ErrInvalidServiceAccountAuthentication = model.ErrInvalidServiceAccountAuthentication
+	ErrInvalidServiceAccountUsageFilter    = model.ErrInvalidServiceAccountUsageFilter
+)
+
+// KubernetesTokenReviewer valida os tokens das contas de serviço de um cluster Kubernetes
+type KubernetesTokenReviewer interface {
+	// Review submete o token a um TokenReview no cluster e retorna o usuário autenticado
+	// (system:serviceaccount:<namespace>:<nome>); um token recusado pelo cluster retorna erro
+	Review(ctx context.Context, token string) (string, error)
+}
+
+// SaveServiceAccountRequest representa a criação ou a alteração de uma conta de serviço
+// Na alteração, AccountID identifica a conta; o estado vazio mantém o atual
+type SaveServiceAccountRequest struct {
+	TenantID    uuid.UUID                  `json:"tenant_id"`
+	AccountID   uuid.UUID                  `json:"account_id,omitempty"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	Status      model.ServiceAccountStatus `json:"status,omitempty"`
+	ActorID     uuid.UUID                  `json:"actor_id"`
+}
+
+// IssueServiceAccountCredentialRequest representa a emissão de uma credencial
+// Os certificados mTLS são registados a partir do certificado em PEM, cuja validade limita a da credencial
+type IssueServiceAccountCredentialRequest struct {
+	TenantID       uuid.UUID                          `json:"tenant_id"`
+	AccountID      uuid.UUID                          `json:"account_id"`
+	Type           model.ServiceAccountCredentialType `json:"type"`
+	Name           string                             `json:"name,omitempty"`
+	ExpiresAt      *time.Time                         `json:"expires_at,omitempty"`
+	CertificatePEM string                             `json:"certificate_pem,omitempty"`
+	ActorID        uuid.UUID                          `json:"actor_id"`
+}
+
+// IssuedServiceAccountCredential é uma credencial acabada de emitir
+// O segredo (a chave de API ou o segredo do cliente OIDC) só é mostrado nesta resposta
+type IssuedServiceAccountCredential struct {
+	Credential *model.ServiceAccountCredential `json:"credential"`
+	Secret     string                          `json:"secret,omitempty"`
+}
+
+// ServiceAccountAuthRequest representa a autenticação de uma conta de serviço com exatamente
+// um método: chave de API, credenciais de cliente OIDC, o certificado apresentado na ligação
+// mTLS ou um token de conta de serviço Kubernetes do cluster indicado
+type ServiceAccountAuthRequest struct {
+	APIKey                 string
+	ClientID               string
+	ClientSecret           string
+	CertificateFingerprint string
+	KubernetesCluster      string
+	KubernetesToken        string
+	IPAddress              string
+}
+
+// ServiceAccountTokenResponse representa o token de acesso emitido a uma conta de serviço autenticada
+type ServiceAccountTokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`
+	ServiceAccountID uuid.UUID `json:"service_account_id"`
+}
+
+// ServiceAccountUsageFilter delimita o relatório de utilização das contas de serviço
+type ServiceAccountUsageFilter struct {
+	// Dias do período contabilizado, até ao dia atual
+	Days int
+	// Apenas as contas inativas
+	DormantOnly bool
+}
+
+// ServiceAccountService define a interface de serviço para as contas de serviço
+type ServiceAccountService interface {
+	// ListServiceAccounts recupera as contas de serviço do tenant
+	ListServiceAccounts(ctx context.Context, tenantID uuid.UUID, filter model.ServiceAccountFilter) ([]*model.ServiceAccount, error)
+
+	// GetServiceAccount recupera uma conta de serviço do tenant
+	GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.ServiceAccount, error)
+
+	// CreateServiceAccount cria uma conta de serviço ativa, sem funções nem credenciais
+	CreateServiceAccount(ctx context.Context, req *SaveServiceAccountRequest) (*model.ServiceAccount, error)
+
+	// UpdateServiceAccount altera o nome, a descrição e o estado de uma conta de serviço
+	// Uma conta desativada deixa de se autenticar, mas mantém as funções e as credenciais
+	UpdateServiceAccount(ctx context.Context, req *SaveServiceAccountRequest) (*model.ServiceAccount, error)
+
+	// BindRole atribui uma função do tenant à conta de serviço
+	BindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID) (*model.ServiceAccount, error)
+
+	// UnbindRole retira uma função da conta de serviço
+	UnbindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID) (*model.ServiceAccount, error)
+
+	// BindWorkloadIdentity liga a conta de serviço a uma conta de serviço Kubernetes de um cluster configurado
+	BindWorkloadIdentity(ctx context.Context, tenantID, accountID uuid.UUID, binding model.WorkloadIdentityBinding, actorID uuid.UUID) (*model.ServiceAccount, error)
+
+	// UnbindWorkloadIdentity remove a ligação da conta de serviço ao Kubernetes
+	UnbindWorkloadIdentity(ctx context.Context, tenantID, accountID, actorID uuid.UUID) (*model.ServiceAccount, error)
+
+	// ListCredentials recupera as credenciais da conta de serviço, sem os segredos
+	ListCredentials(ctx context.Context, tenantID, accountID uuid.UUID) ([]*model.ServiceAccountCredential, error)
+
+	// IssueCredential emite uma chave de API ou um cliente OIDC, ou regista um certificado mTLS
+	IssueCredential(ctx context.Context, req *IssueServiceAccountCredentialRequest) (*IssuedServiceAccountCredential, error)
+
+	// RevokeCredential revoga uma credencial da conta de serviço
+	RevokeCredential(ctx context.Context, tenantID, accountID, credentialID, actorID uuid.UUID) error
+
+	// Authenticate autentica uma conta de serviço ativa e emite um token de acesso de curta duração
+	// com o nome da conta como client_id. Qualquer falha retorna ErrServiceAccountAuthenticationFailed,
+	// sem indicar a causa; as autenticações bem-sucedidas são contabilizadas na utilização da conta
+	Authenticate(ctx context.Context, req *ServiceAccountAuthRequest) (*ServiceAccountTokenResponse, error)
+
+	// UsageReport resume a utilização das contas de serviço do tenant e identifica as contas inativas
+	UsageReport(ctx context.Context, tenantID uuid.UUID, filter ServiceAccountUsageFilter) (*model.ServiceAccountUsageReport, error)
+}
@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos das políticas de sessão
+var (
+	ErrInvalidSessionPolicy       = model.ErrInvalidSessionPolicy
+	ErrSessionPolicyExceedsMarket = model.ErrSessionPolicyExceedsMarket
+)
+
+// UpdateSessionPolicyRequest representa a alteração da política de sessão do tenant
+// Os valores a zero usam os valores padrão do mercado
+type UpdateSessionPolicyRequest struct {
+	TenantID                uuid.UUID `json:"tenant_id"`
+	Market                  string    `json:"market"`
+	FinancialTenant         bool      `json:"financial_tenant"`
+	AccessTokenTTLSeconds   int       `json:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds  int       `json:"refresh_token_ttl_seconds"`
+	AbsoluteLifetimeSeconds int       `json:"absolute_lifetime_seconds"`
+	IdleTimeoutSeconds      int       `json:"idle_timeout_seconds"`
+	UpdatedBy               uuid.UUID `json:"updated_by"`
+}
+
+// SessionPolicyService define a interface de serviço para as políticas de sessão
+type SessionPolicyService interface {
+	// GetPolicy recupera a política de sessão do tenant
+	// Sem política configurada, retorna os valores padrão do mercado indicado
+	GetPolicy(ctx context.Context, tenantID uuid.UUID, market string) (*model.SessionPolicy, error)
+
+	// UpdatePolicy altera a política de sessão do tenant, respeitando os limites do mercado
+	UpdatePolicy(ctx context.Context, req *UpdateSessionPolicyRequest) (*model.SessionPolicy, error)
+
+	// ResetPolicy remove a política do tenant, que volta aos valores padrão do mercado
+	ResetPolicy(ctx context.Context, tenantID, actorID uuid.UUID) error
+
+	// Lifetimes calcula a validade dos tokens a emitir para uma sessão autenticada em authTime
+	Lifetimes(ctx context.Context, tenantID uuid.UUID, market string, authTime time.Time) (*model.SessionLifetimes, error)
+
+	// CheckSession verifica um pedido autenticado contra a política do tenant: a validade do
+	// token, a duração da sessão e a inatividade, registando o pedido na sessão
+	CheckSession(ctx context.Context, check *model.SessionCheck) (*model.SessionDecision, error)
+}
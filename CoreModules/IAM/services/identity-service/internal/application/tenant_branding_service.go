@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da identidade visual e dos modelos de email
+var (
+	ErrInvalidTenantBranding        = model.ErrInvalidTenantBranding
+	ErrInvalidEmailTemplate         = model.ErrInvalidEmailTemplate
+	ErrEmailTemplateNotFound        = model.ErrEmailTemplateNotFound
+	ErrUnknownEmailTemplateVariable = model.ErrUnknownEmailTemplateVariable
+)
+
+// EmailRenderer produz os emails dos fluxos de autoatendimento com a identidade visual do tenant
+// Os fluxos que enviam convites, links de redefinição ou pedidos de inscrição de MFA usam-no para
+// ficar independentes da gestão dos modelos
+type EmailRenderer interface {
+	// RenderEmail produz o email no idioma a partir da versão publicada pelo tenant ou do modelo padrão
+	RenderEmail(ctx context.Context, tenantID uuid.UUID, kind model.EmailTemplateKind, locale string, data *model.EmailTemplateData) (*model.RenderedEmail, error)
+}
+
+// UpdateTenantBrandingRequest representa a alteração da identidade visual do tenant
+type UpdateTenantBrandingRequest struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	DisplayName    string    `json:"display_name"`
+	LogoURL        string    `json:"logo_url,omitempty"`
+	PrimaryColor   string    `json:"primary_color,omitempty"`
+	SecondaryColor string    `json:"secondary_color,omitempty"`
+	SupportEmail   string    `json:"support_email,omitempty"`
+	SupportURL     string    `json:"support_url,omitempty"`
+	FooterText     string    `json:"footer_text,omitempty"`
+	UpdatedBy      uuid.UUID `json:"updated_by"`
+}
+
+// CreateEmailTemplateRequest representa uma nova versão do modelo de um email num idioma
+// A versão é gravada como rascunho e só é enviada depois de publicada
+type CreateEmailTemplateRequest struct {
+	TenantID    uuid.UUID               `json:"tenant_id"`
+	Kind        model.EmailTemplateKind `json:"kind"`
+	Locale      string                  `json:"locale"`
+	Description string                  `json:"description,omitempty"`
+	Subject     string                  `json:"subject"`
+	HTMLBody    string                  `json:"html_body"`
+	TextBody    string                  `json:"text_body,omitempty"`
+	CreatedBy   uuid.UUID               `json:"created_by"`
+}
+
+// ListEmailTemplatesRequest filtra as versões dos modelos do tenant; os campos vazios não filtram
+type ListEmailTemplatesRequest struct {
+	TenantID uuid.UUID                 `json:"tenant_id"`
+	Kind     model.EmailTemplateKind   `json:"kind,omitempty"`
+	Locale   string                    `json:"locale,omitempty"`
+	Status   model.EmailTemplateStatus `json:"status,omitempty"`
+}
+
+// PreviewEmailRequest representa a pré-visualização de um email
+//
+// Com TemplateID é pré-visualizada essa versão; com HTMLBody, o modelo enviado, sem o gravar;
+// sem nenhum dos dois, a versão publicada do email e idioma ou o modelo padrão. As variáveis
+// substituem os valores de exemplo e Branding, quando enviada, a identidade visual gravada
+type PreviewEmailRequest struct {
+	TenantID   uuid.UUID               `json:"tenant_id"`
+	TemplateID *uuid.UUID              `json:"template_id,omitempty"`
+	Kind       model.EmailTemplateKind `json:"kind,omitempty"`
+	Locale     string                  `json:"locale,omitempty"`
+	Subject    string                  `json:"subject,omitempty"`
+	HTMLBody   string                  `json:"html_body,omitempty"`
+	TextBody   string                  `json:"text_body,omitempty"`
+	Variables  map[string]string       `json:"variables,omitempty"`
+	Branding   *model.TenantBranding   `json:"branding,omitempty"`
+}
+
+// EmailTemplateKindVariables lista as variáveis disponíveis nos modelos de um email
+type EmailTemplateKindVariables struct {
+	Kind      model.EmailTemplateKind       `json:"kind"`
+	Variables []model.EmailTemplateVariable `json:"variables"`
+}
+
+// TenantBrandingService define a interface de serviço para a identidade visual e os modelos de email dos tenants
+type TenantBrandingService interface {
+	EmailRenderer
+
+	// GetBranding recupera a identidade visual do tenant
+	GetBranding(ctx context.Context, tenantID uuid.UUID) (*model.TenantBranding, error)
+
+	// UpdateBranding valida e grava a identidade visual do tenant
+	UpdateBranding(ctx context.Context, req *UpdateTenantBrandingRequest) (*model.TenantBranding, error)
+
+	// ListVariables recupera as variáveis disponíveis nos modelos de cada email
+	ListVariables(ctx context.Context) []EmailTemplateKindVariables
+
+	// ListEmailTemplates recupera as versões dos modelos do tenant
+	ListEmailTemplates(ctx context.Context, req *ListEmailTemplatesRequest) ([]*model.EmailTemplate, error)
+
+	// GetEmailTemplate recupera uma versão de um modelo do tenant
+	GetEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error)
+
+	// CreateEmailTemplate valida, sanitiza e grava uma nova versão como rascunho
+	CreateEmailTemplate(ctx context.Context, req *CreateEmailTemplateRequest) (*model.EmailTemplate, error)
+
+	// PublishEmailTemplate publica uma versão no lugar da versão publicada do mesmo email e idioma
+	// A publicação de uma versão arquivada repõe-na
+	PublishEmailTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) (*model.EmailTemplate, error)
+
+	// ArchiveEmailTemplate arquiva uma versão; arquivar a versão publicada repõe o modelo padrão
+	ArchiveEmailTemplate(ctx context.Context, tenantID, templateID, actorID uuid.UUID) (*model.EmailTemplate, error)
+
+	// PreviewEmail produz um email com valores de exemplo sem o enviar
+	PreviewEmail(ctx context.Context, req *PreviewEmailRequest) (*model.RenderedEmail, error)
+}
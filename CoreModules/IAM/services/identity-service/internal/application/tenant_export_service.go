@@ -0,0 +1,88 @@
+package application
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da exportação de dados de tenants
+var (
+	ErrTenantExportNotFound   = model.ErrTenantExportNotFound
+	ErrInvalidTenantExport    = model.ErrInvalidTenantExport
+	ErrTenantExportInProgress = model.ErrTenantExportInProgress
+	ErrTenantExportNotReady   = model.ErrTenantExportNotReady
+	ErrTenantExportConflict   = model.ErrTenantExportConflict
+)
+
+// TenantExportArchive guarda os ficheiros dos arquivos de exportação
+// Os ficheiros são escritos por acréscimo; ao retomar uma exportação, o ficheiro da secção
+// em curso é reposto no último tamanho confirmado antes de novos acréscimos
+type TenantExportArchive interface {
+	// Append acrescenta os dados ao ficheiro, criando-o se necessário, e retorna o tamanho resultante
+	Append(ctx context.Context, exportID uuid.UUID, name string, data []byte) (int64, error)
+
+	// Truncate repõe o ficheiro no tamanho indicado, criando-o vazio se não existir
+	Truncate(ctx context.Context, exportID uuid.UUID, name string, size int64) error
+
+	// Open abre o ficheiro para leitura
+	Open(ctx context.Context, exportID uuid.UUID, name string) (io.ReadCloser, error)
+}
+
+// StartTenantExportRequest representa o pedido de exportação dos dados de um tenant
+// Sem tratamento indicado, aplica-se o tratamento de dados pessoais exigido pelo mercado
+type StartTenantExportRequest struct {
+	TenantID    uuid.UUID                 `json:"tenant_id"`
+	RequestedBy uuid.UUID                 `json:"requested_by"`
+	Market      string                    `json:"market"`
+	Format      model.TenantExportFormat  `json:"format"`
+	PIIMode     model.TenantExportPIIMode `json:"pii_mode"`
+}
+
+// TenantExportSigningKey descreve a chave pública que verifica os manifestos
+type TenantExportSigningKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	// Chave pública codificada em base64
+	PublicKey string `json:"public_key"`
+}
+
+// TenantExportRunResult resume uma passagem de geração das exportações pendentes
+type TenantExportRunResult struct {
+	StartedAt time.Time `json:"started_at"`
+	Processed int       `json:"processed"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+}
+
+// TenantExportService define a interface de serviço para a exportação de dados de tenants
+type TenantExportService interface {
+	// Start regista uma exportação pendente; a geração é feita pelo agendador
+	Start(ctx context.Context, req *StartTenantExportRequest) (*model.TenantExport, error)
+
+	// Get recupera uma exportação pelo ID
+	Get(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error)
+
+	// List recupera as exportações do tenant, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID uuid.UUID) ([]*model.TenantExport, error)
+
+	// Resume devolve à fila uma exportação falhada, que continua a partir do último lote gravado
+	Resume(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error)
+
+	// Run gera a exportação até à conclusão, à falha ou ao cancelamento do contexto
+	Run(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error)
+
+	// RunPending gera as exportações pendentes ou interrompidas de todos os tenants
+	RunPending(ctx context.Context) (*TenantExportRunResult, error)
+
+	// OpenFile abre um ficheiro de uma exportação concluída, incluindo o manifesto
+	// Retorna model.ErrTenantExportNotReady enquanto a exportação não estiver concluída
+	OpenFile(ctx context.Context, tenantID, exportID uuid.UUID, name string) (io.ReadCloser, error)
+
+	// SigningKey retorna a chave pública que verifica os manifestos
+	SigningKey() TenantExportSigningKey
+}
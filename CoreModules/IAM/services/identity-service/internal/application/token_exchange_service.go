@@ -0,0 +1,100 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos da troca de tokens
+var (
+	ErrTokenExchangePolicyNotFound   = model.ErrTokenExchangePolicyNotFound
+	ErrTokenExchangePolicyConflict   = model.ErrTokenExchangePolicyConflict
+	ErrInvalidTokenExchangePolicy    = model.ErrInvalidTokenExchangePolicy
+	ErrInvalidTokenExchangeRequest   = model.ErrInvalidTokenExchangeRequest
+	ErrInvalidSubjectToken           = model.ErrInvalidSubjectToken
+	ErrInvalidActorToken             = model.ErrInvalidActorToken
+	ErrTokenExchangeTargetNotAllowed = model.ErrTokenExchangeTargetNotAllowed
+	ErrTokenExchangeActorNotAllowed  = model.ErrTokenExchangeActorNotAllowed
+	ErrTokenExchangeScopeNotAllowed  = model.ErrTokenExchangeScopeNotAllowed
+	ErrDelegationDepthExceeded       = model.ErrDelegationDepthExceeded
+	ErrInvalidTokenExchangeFilter    = model.ErrInvalidTokenExchangeFilter
+)
+
+// TokenExchangeCodec lê e assina os tokens da troca, mantendo o serviço independente do
+// formato e da biblioteca JWT
+type TokenExchangeCodec interface {
+	// Parse valida a assinatura, o emissor e a validade do token e retorna as suas claims
+	Parse(token string) (*model.TokenClaims, error)
+
+	// Sign assina as claims de um token emitido pela troca
+	Sign(claims *model.TokenClaims) (string, error)
+}
+
+// TokenExchangeRequest representa um pedido de troca de tokens (RFC 8693, secção 2.1)
+// O serviço que age apresenta o token do titular em SubjectToken e o seu próprio token em
+// ActorToken; Audience identifica o serviço a que o token emitido se destina
+type TokenExchangeRequest struct {
+	GrantType          string   `json:"grant_type"`
+	SubjectToken       string   `json:"subject_token"`
+	SubjectTokenType   string   `json:"subject_token_type"`
+	ActorToken         string   `json:"actor_token,omitempty"`
+	ActorTokenType     string   `json:"actor_token_type,omitempty"`
+	Audience           string   `json:"audience,omitempty"`
+	Resource           string   `json:"resource,omitempty"`
+	Scopes             []string `json:"scopes,omitempty"`
+	RequestedTokenType string   `json:"requested_token_type,omitempty"`
+	IPAddress          string   `json:"-"`
+}
+
+// TokenExchangeResponse representa a resposta de uma troca concedida (RFC 8693, secção 2.2.1)
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	Scope           string `json:"scope"`
+}
+
+// SaveTokenExchangePolicyRequest representa a criação ou a alteração de uma política de troca de tokens
+// Na alteração, PolicyID identifica a política e todos os campos são substituídos
+type SaveTokenExchangePolicyRequest struct {
+	TenantID           uuid.UUID `json:"tenant_id"`
+	PolicyID           uuid.UUID `json:"policy_id,omitempty"`
+	Audience           string    `json:"audience"`
+	Description        string    `json:"description,omitempty"`
+	AllowedActors      []string  `json:"allowed_actors"`
+	AllowedScopes      []string  `json:"allowed_scopes"`
+	MaxTTLSeconds      int       `json:"max_ttl_seconds"`
+	MaxDelegationDepth int       `json:"max_delegation_depth,omitempty"`
+	Enabled            bool      `json:"enabled"`
+	ActorID            uuid.UUID `json:"actor_id"`
+}
+
+// TokenExchangeService define a interface de serviço para a troca de tokens entre serviços
+type TokenExchangeService interface {
+	// Exchange troca o token do titular e o do serviço que age por um token delegado para a
+	// audiência pedida, com os escopos reduzidos pela política da audiência e a claim act.
+	// Concedida ou recusada, a troca fica no registo de auditoria
+	Exchange(ctx context.Context, req *TokenExchangeRequest) (*TokenExchangeResponse, error)
+
+	// ListPolicies recupera as políticas de troca de tokens do tenant
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.TokenExchangePolicy, error)
+
+	// GetPolicy recupera uma política de troca de tokens do tenant
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.TokenExchangePolicy, error)
+
+	// CreatePolicy cria uma política de troca de tokens
+	CreatePolicy(ctx context.Context, req *SaveTokenExchangePolicyRequest) (*model.TokenExchangePolicy, error)
+
+	// UpdatePolicy altera uma política de troca de tokens
+	UpdatePolicy(ctx context.Context, req *SaveTokenExchangePolicyRequest) (*model.TokenExchangePolicy, error)
+
+	// DeletePolicy remove uma política de troca de tokens
+	DeletePolicy(ctx context.Context, tenantID, policyID, actorID uuid.UUID) error
+
+	// ListEvents recupera o registo de auditoria das trocas de tokens do tenant
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.TokenExchangeEventFilter) ([]*model.TokenExchangeEvent, error)
+}
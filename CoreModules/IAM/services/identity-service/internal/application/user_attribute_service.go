@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos dos atributos dos usuários
+var (
+	ErrUserAttributeSchemaNotFound = model.ErrUserAttributeSchemaNotFound
+	ErrUserAttributeSchemaConflict = model.ErrUserAttributeSchemaConflict
+	ErrInvalidUserAttributeSchema  = model.ErrInvalidUserAttributeSchema
+	ErrUserAttributeSchemaLimit    = model.ErrUserAttributeSchemaLimit
+	ErrInvalidUserAttributes       = model.ErrInvalidUserAttributes
+)
+
+// UserClaimsResolver resolve as claims personalizadas dos tokens emitidos a um usuário
+type UserClaimsResolver interface {
+	// ResolveClaims retorna as claims dos atributos do usuário cujo esquema indica uma claim
+	// Retorna model.ErrUserNotFound quando o titular não é um usuário do tenant
+	ResolveClaims(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error)
+}
+
+// SaveUserAttributeSchemaRequest representa a criação ou a alteração de um esquema de atributo
+// Na alteração, SchemaID identifica o esquema; o nome e o tipo têm de ser os do esquema atual
+type SaveUserAttributeSchemaRequest struct {
+	TenantID    uuid.UUID               `json:"tenant_id"`
+	SchemaID    uuid.UUID               `json:"schema_id,omitempty"`
+	Name        string                  `json:"name"`
+	DisplayName string                  `json:"display_name,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Type        model.UserAttributeType `json:"type"`
+	Required    bool                    `json:"required"`
+	EnumValues  []string                `json:"enum_values,omitempty"`
+	Pattern     string                  `json:"pattern,omitempty"`
+	MaxLength   int                     `json:"max_length,omitempty"`
+	MinValue    *float64                `json:"min_value,omitempty"`
+	MaxValue    *float64                `json:"max_value,omitempty"`
+	ClaimName   string                  `json:"claim_name,omitempty"`
+	ActorID     uuid.UUID               `json:"actor_id"`
+}
+
+// SetUserAttributesRequest representa a substituição dos atributos de um usuário
+type SetUserAttributesRequest struct {
+	TenantID uuid.UUID              `json:"tenant_id"`
+	UserID   uuid.UUID              `json:"user_id"`
+	Values   map[string]interface{} `json:"values"`
+	ActorID  uuid.UUID              `json:"actor_id"`
+}
+
+// UserAttributeService define a interface de serviço para os atributos personalizados dos usuários
+// Os esquemas do tenant ficam em cache; as alterações feitas noutras instâncias são aplicadas
+// quando o cache expira
+type UserAttributeService interface {
+	UserClaimsResolver
+
+	// ListSchemas recupera os esquemas dos atributos do tenant
+	ListSchemas(ctx context.Context, tenantID uuid.UUID) ([]*model.UserAttributeSchema, error)
+
+	// GetSchema recupera um esquema do tenant
+	GetSchema(ctx context.Context, tenantID, schemaID uuid.UUID) (*model.UserAttributeSchema, error)
+
+	// CreateSchema cria um esquema de atributo
+	// Um atributo obrigatório só é exigido nas escritas seguintes dos atributos de cada usuário
+	CreateSchema(ctx context.Context, req *SaveUserAttributeSchemaRequest) (*model.UserAttributeSchema, error)
+
+	// UpdateSchema altera as restrições, a obrigatoriedade e a claim de um esquema
+	// Os valores gravados que deixam de ser válidos são ignorados até serem corrigidos
+	UpdateSchema(ctx context.Context, req *SaveUserAttributeSchemaRequest) (*model.UserAttributeSchema, error)
+
+	// DeleteSchema remove o esquema e o atributo de todos os usuários do tenant
+	DeleteSchema(ctx context.Context, tenantID, schemaID, actorID uuid.UUID) error
+
+	// GetUserAttributes recupera os atributos gravados do usuário
+	GetUserAttributes(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributes, error)
+
+	// SetUserAttributes valida os atributos contra os esquemas do tenant e substitui os do usuário
+	SetUserAttributes(ctx context.Context, req *SetUserAttributesRequest) (*model.UserAttributes, error)
+
+	// ResolveAttributes retorna os atributos válidos do usuário, para o contexto das políticas ABAC
+	ResolveAttributes(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error)
+}
@@ -0,0 +1,58 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros específicos do ciclo de vida de usuários
+var (
+	ErrInvalidUserTransition       = model.ErrInvalidUserTransition
+	ErrInvalidUserLifecyclePolicy  = model.ErrInvalidUserLifecyclePolicy
+	ErrUserLifecyclePolicyNotFound = model.ErrUserLifecyclePolicyNotFound
+)
+
+// UserTransitionRequest representa um pedido de mudança de estado da conta de um usuário
+type UserTransitionRequest struct {
+	TenantID uuid.UUID        `json:"tenant_id"`
+	UserID   uuid.UUID        `json:"user_id"`
+	Target   model.UserStatus `json:"target"`
+	Reason   string           `json:"reason"`
+	ActorID  *uuid.UUID       `json:"actor_id,omitempty"`
+}
+
+// UserLifecycleSweepResult resume uma execução das transições automáticas
+type UserLifecycleSweepResult struct {
+	StartedAt        time.Time `json:"started_at"`
+	TenantsProcessed int       `json:"tenants_processed"`
+	Suspended        int       `json:"suspended"`
+	Deprovisioned    int       `json:"deprovisioned"`
+	Failed           int       `json:"failed"`
+}
+
+// UserLifecycleService define a interface de serviço para o ciclo de vida de contas de usuário
+type UserLifecycleService interface {
+	// Invite coloca um usuário recém-criado no estado convidado, com a validade
+	// definida pela política do tenant
+	Invite(ctx context.Context, tenantID, userID uuid.UUID, actorID *uuid.UUID) (*model.User, error)
+
+	// Transition aplica uma transição guardada e publica o evento correspondente
+	Transition(ctx context.Context, req *UserTransitionRequest) (*model.User, error)
+
+	// GetTransitions recupera o histórico de transições do usuário
+	GetTransitions(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.UserLifecycleTransition, error)
+
+	// GetPolicy recupera a política do tenant ou a política padrão quando não configurada
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.UserLifecyclePolicy, error)
+
+	// SetPolicy configura a política de ciclo de vida do tenant
+	SetPolicy(ctx context.Context, policy *model.UserLifecyclePolicy) error
+
+	// RunScheduledTransitions suspende contas inativas e desprovisiona contas suspensas
+	// há mais tempo que o prazo da política de cada tenant
+	RunScheduledTransitions(ctx context.Context) (*UserLifecycleSweepResult, error)
+}
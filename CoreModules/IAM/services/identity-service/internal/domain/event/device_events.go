@@ -0,0 +1,80 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Definição de eventos do registo de dispositivos dos usuários.
+ * Os eventos são publicados no fluxo de auditoria, para o SIEM e a deteção de anomalias.
+ * Segue princípios de Event-Driven Architecture e Domain-Driven Design (DDD).
+ */
+
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+const (
+	// Tópicos para eventos do registo de dispositivos
+	TopicDeviceRegistered       = "iam.device.registered"
+	TopicDeviceRenamed          = "iam.device.renamed"
+	TopicDeviceTrusted          = "iam.device.trusted"
+	TopicDeviceUntrusted        = "iam.device.untrusted"
+	TopicDeviceRevoked          = "iam.device.revoked"
+	TopicDeviceFingerprintReuse = "iam.device.fingerprint_reused"
+)
+
+// DeviceEvent evento emitido a cada alteração de um dispositivo de um usuário
+// O tipo do evento é o tópico da alteração
+type DeviceEvent struct {
+	Topic          string             `json:"-"`
+	TenantID       uuid.UUID          `json:"tenant_id"`
+	UserID         uuid.UUID          `json:"user_id"`
+	DeviceID       uuid.UUID          `json:"device_id"`
+	DeviceName     string             `json:"device_name"`
+	Status         model.DeviceStatus `json:"status"`
+	IPAddress      string             `json:"ip_address,omitempty"`
+	Country        string             `json:"country,omitempty"`
+	TrustExpiresAt *time.Time         `json:"trust_expires_at,omitempty"`
+	// Contas do tenant que usam o mesmo dispositivo, nos eventos de partilha
+	SharedWith []uuid.UUID `json:"shared_with,omitempty"`
+	// ActorID é nulo nas alterações feitas pelo próprio login
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	EventTime time.Time  `json:"event_time"`
+}
+
+// NewDeviceEvent cria o evento da alteração do dispositivo no tópico indicado
+func NewDeviceEvent(topic string, device *model.Device, actorID *uuid.UUID, at time.Time) *DeviceEvent {
+	return &DeviceEvent{
+		Topic:          topic,
+		TenantID:       device.TenantID,
+		UserID:         device.UserID,
+		DeviceID:       device.ID,
+		DeviceName:     device.Name,
+		Status:         device.Status,
+		IPAddress:      device.LastIPAddress,
+		Country:        device.LastCountry,
+		TrustExpiresAt: device.TrustExpiresAt,
+		ActorID:        actorID,
+		EventTime:      at,
+	}
+}
+
+func (e *DeviceEvent) GetType() string {
+	return e.Topic
+}
+
+func (e *DeviceEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+func (e *DeviceEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
+
+func (e *DeviceEvent) GetUserID() uuid.UUID {
+	return e.UserID
+}
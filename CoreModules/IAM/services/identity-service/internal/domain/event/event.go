@@ -20,6 +20,12 @@ type Event interface {
 	GetTime() time.Time
 }
 
+// Publisher interface para publicação de eventos para sistemas externos
+// O tópico de destino é o tipo do evento
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
 // EventBus interface para publicação e assinatura de eventos
 type EventBus interface {
 	Publish(ctx context.Context, eventType string, event Event) error
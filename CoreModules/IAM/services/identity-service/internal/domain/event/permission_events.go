@@ -0,0 +1,72 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Definição de eventos relacionados a permissões no sistema IAM.
+ * Os eventos notificam as alterações das permissões aos consumidores, como as caches de leitura.
+ * Segue princípios de Event-Driven Architecture e Domain-Driven Design (DDD).
+ */
+
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Tópicos para eventos relacionados a permissões
+	TopicPermissionCreated = "iam.permission.created"
+	TopicPermissionUpdated = "iam.permission.updated"
+	TopicPermissionDeleted = "iam.permission.deleted"
+)
+
+// PermissionEvent interface base para eventos relacionados a permissões
+type PermissionEvent interface {
+	Event
+	GetPermissionID() uuid.UUID
+	GetTenantID() uuid.UUID
+	GetCode() string
+}
+
+// PermissionChangedEvent evento emitido quando uma permissão é criada, atualizada ou excluída
+// O tipo do evento é o tópico da alteração
+type PermissionChangedEvent struct {
+	Topic        string    `json:"-"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	PermissionID uuid.UUID `json:"permission_id"`
+	Code         string    `json:"code"`
+	EventTime    time.Time `json:"event_time"`
+}
+
+func (e *PermissionChangedEvent) GetType() string {
+	return e.Topic
+}
+
+func (e *PermissionChangedEvent) GetPermissionID() uuid.UUID {
+	return e.PermissionID
+}
+
+func (e *PermissionChangedEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
+
+func (e *PermissionChangedEvent) GetCode() string {
+	return e.Code
+}
+
+func (e *PermissionChangedEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+// NewPermissionChangedEvent cria o evento de alteração da permissão no tópico indicado
+func NewPermissionChangedEvent(topic string, tenantID, permissionID uuid.UUID, code string) *PermissionChangedEvent {
+	return &PermissionChangedEvent{
+		Topic:        topic,
+		TenantID:     tenantID,
+		PermissionID: permissionID,
+		Code:         code,
+		EventTime:    time.Now().UTC(),
+	}
+}
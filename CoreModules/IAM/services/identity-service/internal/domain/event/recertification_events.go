@@ -0,0 +1,101 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Definição de eventos das campanhas de recertificação de acessos.
+ * Os eventos são publicados no Kafka para notificar os revisores e entregar a atestação à auditoria.
+ * Segue princípios de Event-Driven Architecture e Domain-Driven Design (DDD).
+ */
+
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+const (
+	// Tópicos para eventos das campanhas de recertificação
+	TopicRecertificationReviewRequested   = "iam.recertification.review.requested"
+	TopicRecertificationReviewEscalated   = "iam.recertification.review.escalated"
+	TopicRecertificationCampaignCompleted = "iam.recertification.campaign.completed"
+)
+
+// RecertificationReviewEvent evento emitido para notificar um revisor dos itens que tem por decidir
+type RecertificationReviewEvent struct {
+	CampaignID   uuid.UUID `json:"campaign_id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	CampaignName string    `json:"campaign_name"`
+	ReviewerID   uuid.UUID `json:"reviewer_id"`
+	Items        int       `json:"items"`
+	Deadline     time.Time `json:"deadline"`
+	Escalated    bool      `json:"escalated"`
+	EventTime    time.Time `json:"event_time"`
+}
+
+// NewRecertificationReviewEvent cria a notificação de um revisor da campanha
+func NewRecertificationReviewEvent(campaign *model.RecertificationCampaign, reviewerID uuid.UUID, items int, escalated bool, now time.Time) *RecertificationReviewEvent {
+	return &RecertificationReviewEvent{
+		CampaignID:   campaign.ID,
+		TenantID:     campaign.TenantID,
+		CampaignName: campaign.Name,
+		ReviewerID:   reviewerID,
+		Items:        items,
+		Deadline:     campaign.Deadline,
+		Escalated:    escalated,
+		EventTime:    now,
+	}
+}
+
+// GetType retorna o tópico de acordo com a notificação ser um escalamento
+func (e *RecertificationReviewEvent) GetType() string {
+	if e.Escalated {
+		return TopicRecertificationReviewEscalated
+	}
+	return TopicRecertificationReviewRequested
+}
+
+func (e *RecertificationReviewEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+func (e *RecertificationReviewEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
+
+// RecertificationCampaignCompletedEvent evento emitido quando uma campanha é concluída
+type RecertificationCampaignCompletedEvent struct {
+	CampaignID uuid.UUID                    `json:"campaign_id"`
+	TenantID   uuid.UUID                    `json:"tenant_id"`
+	Name       string                       `json:"name"`
+	Summary    model.RecertificationSummary `json:"summary"`
+	Digest     string                       `json:"digest"`
+	EventTime  time.Time                    `json:"event_time"`
+}
+
+// NewRecertificationCampaignCompletedEvent cria o evento a partir da atestação da campanha
+func NewRecertificationCampaignCompletedEvent(attestation *model.RecertificationAttestation) *RecertificationCampaignCompletedEvent {
+	return &RecertificationCampaignCompletedEvent{
+		CampaignID: attestation.Campaign.ID,
+		TenantID:   attestation.Campaign.TenantID,
+		Name:       attestation.Campaign.Name,
+		Summary:    attestation.Summary,
+		Digest:     attestation.Digest,
+		EventTime:  *attestation.Campaign.CompletedAt,
+	}
+}
+
+func (e *RecertificationCampaignCompletedEvent) GetType() string {
+	return TopicRecertificationCampaignCompleted
+}
+
+func (e *RecertificationCampaignCompletedEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+func (e *RecertificationCampaignCompletedEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
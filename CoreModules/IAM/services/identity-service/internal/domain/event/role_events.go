@@ -126,6 +126,7 @@ type RoleHardDeletedEvent struct {
 	TenantID    uuid.UUID  `json:"tenant_id"`
 	RoleID      uuid.UUID  `json:"role_id"`
 	Code        string     `json:"code"`
+	DeletedBy   *uuid.UUID `json:"deleted_by,omitempty"`
 	EventTime   time.Time  `json:"event_time"`
 }
 
@@ -151,6 +152,7 @@ type PermissionsAssignedToRoleEvent struct {
 	RoleID        uuid.UUID    `json:"role_id"`
 	RoleCode      string       `json:"role_code"`
 	PermissionIDs []uuid.UUID  `json:"permission_ids"`
+	AssignedBy    *uuid.UUID   `json:"assigned_by,omitempty"`
 	EventTime     time.Time    `json:"event_time"`
 }
 
@@ -204,6 +206,7 @@ type RoleAssignedToUsersEvent struct {
 	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
 	ActivatesAt *time.Time   `json:"activates_at,omitempty"`
 	AssignedAt  time.Time    `json:"assigned_at"`
+	AssignedBy  *uuid.UUID   `json:"assigned_by,omitempty"`
 	EventTime   time.Time    `json:"event_time"`
 }
 
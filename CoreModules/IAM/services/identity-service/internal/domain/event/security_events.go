@@ -0,0 +1,59 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Definição de eventos de segurança emitidos pela análise do fluxo de auditoria.
+ * Os eventos são publicados no Kafka para encaminhamento ao SIEM e à equipa de segurança.
+ * Segue princípios de Event-Driven Architecture e Domain-Driven Design (DDD).
+ */
+
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+const (
+	// Tópico para anomalias detetadas no fluxo de auditoria
+	TopicSecurityAnomalyDetected = "iam.security.anomaly.detected"
+)
+
+// SecurityAnomalyDetectedEvent evento emitido quando a análise de auditoria abre um incidente
+type SecurityAnomalyDetectedEvent struct {
+	IncidentID uuid.UUID                      `json:"incident_id"`
+	TenantID   uuid.UUID                      `json:"tenant_id"`
+	Rule       model.SecurityIncidentRule     `json:"rule"`
+	Severity   model.SecurityIncidentSeverity `json:"severity"`
+	ActorID    *uuid.UUID                     `json:"actor_id,omitempty"`
+	Summary    string                         `json:"summary"`
+	EventTime  time.Time                      `json:"event_time"`
+}
+
+// NewSecurityAnomalyDetectedEvent cria o evento correspondente a um incidente aberto
+func NewSecurityAnomalyDetectedEvent(incident *model.SecurityIncident) *SecurityAnomalyDetectedEvent {
+	return &SecurityAnomalyDetectedEvent{
+		IncidentID: incident.ID,
+		TenantID:   incident.TenantID,
+		Rule:       incident.Rule,
+		Severity:   incident.Severity,
+		ActorID:    incident.ActorID,
+		Summary:    incident.Summary,
+		EventTime:  incident.DetectedAt,
+	}
+}
+
+func (e *SecurityAnomalyDetectedEvent) GetType() string {
+	return TopicSecurityAnomalyDetected
+}
+
+func (e *SecurityAnomalyDetectedEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+func (e *SecurityAnomalyDetectedEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
@@ -0,0 +1,92 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Definição de eventos do ciclo de vida de contas de usuário no sistema IAM.
+ * Os eventos são publicados no Kafka para sincronização com sistemas de RH e ITSM.
+ * Segue princípios de Event-Driven Architecture e Domain-Driven Design (DDD).
+ */
+
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+const (
+	// Tópicos para eventos do ciclo de vida de usuários
+	TopicUserInvited       = "iam.user.lifecycle.invited"
+	TopicUserActivated     = "iam.user.lifecycle.activated"
+	TopicUserReactivated   = "iam.user.lifecycle.reactivated"
+	TopicUserLocked        = "iam.user.lifecycle.locked"
+	TopicUserSuspended     = "iam.user.lifecycle.suspended"
+	TopicUserDeprovisioned = "iam.user.lifecycle.deprovisioned"
+)
+
+// UserLifecycleEvent evento emitido a cada transição de estado da conta de um usuário
+type UserLifecycleEvent struct {
+	TransitionID uuid.UUID        `json:"transition_id"`
+	TenantID     uuid.UUID        `json:"tenant_id"`
+	UserID       uuid.UUID        `json:"user_id"`
+	Username     string           `json:"username"`
+	Email        string           `json:"email"`
+	FromStatus   model.UserStatus `json:"from_status"`
+	ToStatus     model.UserStatus `json:"to_status"`
+	Reason       string           `json:"reason,omitempty"`
+	ActorID      *uuid.UUID       `json:"actor_id,omitempty"`
+	Automated    bool             `json:"automated"`
+	EventTime    time.Time        `json:"event_time"`
+}
+
+// NewUserLifecycleEvent cria o evento correspondente a uma transição aplicada ao usuário
+func NewUserLifecycleEvent(user *model.User, transition *model.UserLifecycleTransition) *UserLifecycleEvent {
+	return &UserLifecycleEvent{
+		TransitionID: transition.ID,
+		TenantID:     transition.TenantID,
+		UserID:       transition.UserID,
+		Username:     user.Username,
+		Email:        user.Email,
+		FromStatus:   transition.FromStatus,
+		ToStatus:     transition.ToStatus,
+		Reason:       transition.Reason,
+		ActorID:      transition.ActorID,
+		Automated:    transition.Automated(),
+		EventTime:    transition.OccurredAt,
+	}
+}
+
+// GetType retorna o tópico do evento de acordo com o estado de destino
+// A saída de uma suspensão ou bloqueio é publicada como reativação
+func (e *UserLifecycleEvent) GetType() string {
+	switch e.ToStatus {
+	case model.UserStatusInvited:
+		return TopicUserInvited
+	case model.UserStatusActive:
+		if e.FromStatus == model.UserStatusSuspended || e.FromStatus == model.UserStatusLocked {
+			return TopicUserReactivated
+		}
+		return TopicUserActivated
+	case model.UserStatusLocked:
+		return TopicUserLocked
+	case model.UserStatusSuspended:
+		return TopicUserSuspended
+	default:
+		return TopicUserDeprovisioned
+	}
+}
+
+func (e *UserLifecycleEvent) GetTime() time.Time {
+	return e.EventTime
+}
+
+func (e *UserLifecycleEvent) GetTenantID() uuid.UUID {
+	return e.TenantID
+}
+
+func (e *UserLifecycleEvent) GetUserID() uuid.UUID {
+	return e.UserID
+}
@@ -0,0 +1,251 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Pedidos de acesso em autoatendimento.
+ * Um usuário pede uma função ou permissão com justificação; um aprovador (dono da função
+ * ou administrador delegado do tenant) decide e a aprovação concede o acesso, com validade opcional.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessRequestTarget identifica o tipo de acesso pedido
+type AccessRequestTarget string
+
+// Tipos de acesso que podem ser pedidos
+const (
+	AccessRequestTargetRole       AccessRequestTarget = "role"
+	AccessRequestTargetPermission AccessRequestTarget = "permission"
+)
+
+// AccessRequestStatus representa o estado de um pedido de acesso
+type AccessRequestStatus string
+
+// Estados de um pedido de acesso
+const (
+	AccessRequestStatusPending   AccessRequestStatus = "pending"
+	AccessRequestStatusApproved  AccessRequestStatus = "approved"
+	AccessRequestStatusRejected  AccessRequestStatus = "rejected"
+	AccessRequestStatusCancelled AccessRequestStatus = "cancelled"
+)
+
+// AccessApproverKind identifica a origem da autoridade de um aprovador
+type AccessApproverKind string
+
+// Tipos de aprovador
+const (
+	// O dono decide os pedidos da função que lhe está atribuída
+	AccessApproverKindRoleOwner AccessApproverKind = "role_owner"
+	// O administrador delegado decide qualquer pedido do tenant
+	AccessApproverKindDelegatedAdmin AccessApproverKind = "delegated_admin"
+)
+
+// Tamanho mínimo da justificação de um pedido de acesso
+const MinAccessRequestJustificationLength = 10
+
+// Erros dos pedidos de acesso
+var (
+	ErrAccessRequestNotFound              = errors.New("pedido de acesso não encontrado")
+	ErrInvalidAccessRequest               = errors.New("pedido de acesso inválido")
+	ErrAccessRequestJustificationRequired = errors.New("justificação obrigatória para o pedido de acesso")
+	ErrAccessRequestNotPending            = errors.New("pedido de acesso já decidido")
+	ErrAccessRequestConflict              = errors.New("pedido de acesso alterado concorrentemente")
+	ErrDuplicateAccessRequest             = errors.New("já existe um pedido de acesso pendente para o mesmo destino")
+	ErrAccessAlreadyGranted               = errors.New("o usuário já possui o acesso pedido")
+	ErrAccessRequestSelfApproval          = errors.New("o requerente não pode decidir o próprio pedido de acesso")
+	ErrAccessRequestApproverNotAuthorized = errors.New("usuário sem autoridade para decidir o pedido de acesso")
+	ErrAccessRequestNotRequester          = errors.New("apenas o requerente pode cancelar o pedido de acesso")
+	ErrAccessApproverNotFound             = errors.New("aprovador de pedidos de acesso não encontrado")
+	ErrInvalidAccessApprover              = errors.New("aprovador de pedidos de acesso inválido")
+)
+
+// AccessRequest representa o pedido de uma função ou permissão por um usuário
+type AccessRequest struct {
+	ID                 uuid.UUID           `json:"id"`
+	TenantID           uuid.UUID           `json:"tenant_id"`
+	RequesterID        uuid.UUID           `json:"requester_id"`
+	TargetType         AccessRequestTarget `json:"target_type"`
+	TargetID           uuid.UUID           `json:"target_id"`
+	Justification      string              `json:"justification"`
+	RequestedExpiresAt *time.Time          `json:"requested_expires_at,omitempty"`
+	Status             AccessRequestStatus `json:"status"`
+	DecidedBy          *uuid.UUID          `json:"decided_by,omitempty"`
+	DecisionReason     string              `json:"decision_reason,omitempty"`
+	GrantExpiresAt     *time.Time          `json:"grant_expires_at,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+	DecidedAt          *time.Time          `json:"decided_at,omitempty"`
+}
+
+// NewAccessRequest cria um pedido de acesso pendente
+func NewAccessRequest(
+	tenantID, requesterID uuid.UUID,
+	targetType AccessRequestTarget,
+	targetID uuid.UUID,
+	justification string,
+	requestedExpiresAt *time.Time,
+	now time.Time,
+) (*AccessRequest, error) {
+	req := &AccessRequest{
+		ID:                 uuid.New(),
+		TenantID:           tenantID,
+		RequesterID:        requesterID,
+		TargetType:         targetType,
+		TargetID:           targetID,
+		Justification:      strings.TrimSpace(justification),
+		RequestedExpiresAt: requestedExpiresAt,
+		Status:             AccessRequestStatusPending,
+		CreatedAt:          now,
+	}
+
+	if err := req.validate(now); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// validate verifica os dados de um novo pedido
+func (r *AccessRequest) validate(now time.Time) error {
+	if r.TenantID == uuid.Nil || r.RequesterID == uuid.Nil || r.TargetID == uuid.Nil {
+		return fmt.Errorf("%w: tenant, requerente e destino são obrigatórios", ErrInvalidAccessRequest)
+	}
+	if r.TargetType != AccessRequestTargetRole && r.TargetType != AccessRequestTargetPermission {
+		return fmt.Errorf("%w: tipo de destino desconhecido %q", ErrInvalidAccessRequest, r.TargetType)
+	}
+	if len([]rune(r.Justification)) < MinAccessRequestJustificationLength {
+		return fmt.Errorf("%w: mínimo de %d caracteres", ErrAccessRequestJustificationRequired, MinAccessRequestJustificationLength)
+	}
+	if r.RequestedExpiresAt != nil && !r.RequestedExpiresAt.After(now) {
+		return fmt.Errorf("%w: a validade pedida já expirou", ErrInvalidAccessRequest)
+	}
+	return nil
+}
+
+// IsPending indica se o pedido aguarda decisão
+func (r *AccessRequest) IsPending() bool {
+	return r.Status == AccessRequestStatusPending
+}
+
+// PendingFor devolve há quanto tempo o pedido aguarda decisão, ou o tempo que levou a ser decidido
+func (r *AccessRequest) PendingFor(now time.Time) time.Duration {
+	if r.DecidedAt != nil {
+		return r.DecidedAt.Sub(r.CreatedAt)
+	}
+	return now.Sub(r.CreatedAt)
+}
+
+// Approve aprova o pedido. A validade da concessão é a indicada pelo aprovador ou, na sua
+// ausência, a pedida pelo requerente
+func (r *AccessRequest) Approve(approverID uuid.UUID, reason string, expiresAt *time.Time, now time.Time) error {
+	if expiresAt == nil {
+		expiresAt = r.RequestedExpiresAt
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: a validade da concessão já expirou", ErrInvalidAccessRequest)
+	}
+
+	if err := r.decide(AccessRequestStatusApproved, approverID, reason, now); err != nil {
+		return err
+	}
+	r.GrantExpiresAt = expiresAt
+	return nil
+}
+
+// Reject rejeita o pedido. O motivo é obrigatório para o requerente perceber a decisão
+func (r *AccessRequest) Reject(approverID uuid.UUID, reason string, now time.Time) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("%w: motivo obrigatório para a rejeição", ErrInvalidAccessRequest)
+	}
+	return r.decide(AccessRequestStatusRejected, approverID, reason, now)
+}
+
+// Cancel retira o pedido a pedido do requerente
+func (r *AccessRequest) Cancel(requesterID uuid.UUID, now time.Time) error {
+	if requesterID != r.RequesterID {
+		return ErrAccessRequestNotRequester
+	}
+	return r.decide(AccessRequestStatusCancelled, requesterID, "", now)
+}
+
+// decide regista a decisão de um pedido pendente
+func (r *AccessRequest) decide(status AccessRequestStatus, actorID uuid.UUID, reason string, now time.Time) error {
+	if !r.IsPending() {
+		return fmt.Errorf("%w: estado atual %s", ErrAccessRequestNotPending, r.Status)
+	}
+
+	decidedBy := actorID
+	decidedAt := now
+	r.Status = status
+	r.DecidedBy = &decidedBy
+	r.DecisionReason = strings.TrimSpace(reason)
+	r.DecidedAt = &decidedAt
+	return nil
+}
+
+// AccessRequestFilter restringe a listagem de pedidos de acesso
+type AccessRequestFilter struct {
+	Status      AccessRequestStatus
+	RequesterID *uuid.UUID
+	// Quando não vazio, limita os pedidos de função às funções indicadas
+	RoleIDs []uuid.UUID
+	// Quando verdadeiro, exclui os pedidos de permissão
+	RolesOnly    bool
+	DecidedSince *time.Time
+}
+
+// AccessRequestApprover atribui a um usuário a autoridade para decidir pedidos de acesso
+type AccessRequestApprover struct {
+	ID        uuid.UUID          `json:"id"`
+	TenantID  uuid.UUID          `json:"tenant_id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	Kind      AccessApproverKind `json:"kind"`
+	RoleID    *uuid.UUID         `json:"role_id,omitempty"` // Obrigatório para donos de função
+	CreatedBy uuid.UUID          `json:"created_by"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Validate verifica a coerência do tipo de aprovador com a função indicada
+func (a *AccessRequestApprover) Validate() error {
+	if a.TenantID == uuid.Nil || a.UserID == uuid.Nil {
+		return fmt.Errorf("%w: tenant e usuário são obrigatórios", ErrInvalidAccessApprover)
+	}
+
+	switch a.Kind {
+	case AccessApproverKindRoleOwner:
+		if a.RoleID == nil {
+			return fmt.Errorf("%w: o dono de função exige a função", ErrInvalidAccessApprover)
+		}
+	case AccessApproverKindDelegatedAdmin:
+		if a.RoleID != nil {
+			return fmt.Errorf("%w: o administrador delegado abrange todo o tenant", ErrInvalidAccessApprover)
+		}
+	default:
+		return fmt.Errorf("%w: tipo desconhecido %q", ErrInvalidAccessApprover, a.Kind)
+	}
+	return nil
+}
+
+// Covers indica se o aprovador pode decidir o pedido
+// Os pedidos de permissão só podem ser decididos por administradores delegados
+func (a *AccessRequestApprover) Covers(req *AccessRequest) bool {
+	if a.TenantID != req.TenantID {
+		return false
+	}
+
+	switch a.Kind {
+	case AccessApproverKindDelegatedAdmin:
+		return true
+	case AccessApproverKindRoleOwner:
+		return req.TargetType == AccessRequestTargetRole && a.RoleID != nil && *a.RoleID == req.TargetID
+	default:
+		return false
+	}
+}
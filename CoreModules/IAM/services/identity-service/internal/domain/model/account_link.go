@@ -0,0 +1,129 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Ligação de contas entre fornecedores de identidade: um usuário pode entrar com senha
+ * e com uma ou mais identidades federadas. Quando um login federado chega com um NameID
+ * desconhecido e um email que corresponde a uma conta local com email verificado, é
+ * registado um candidato à ligação; a ligação e a remoção são sempre explícitas, exigem
+ * autenticação recente e ficam gravadas no registo de auditoria.
+ */
+
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkedIdentityKind representa o tipo de método de login de um usuário
+type LinkedIdentityKind string
+
+// Tipos de métodos de login
+const (
+	LinkedIdentityPassword LinkedIdentityKind = "password"
+	LinkedIdentitySAML     LinkedIdentityKind = "saml"
+)
+
+// LinkedIdentity descreve um método de login do usuário: a senha local ou uma identidade federada
+type LinkedIdentity struct {
+	// ID da credencial local ou da identidade federada
+	ID           uuid.UUID          `json:"id"`
+	Kind         LinkedIdentityKind `json:"kind"`
+	ProviderID   *uuid.UUID         `json:"provider_id,omitempty"`
+	ProviderName string             `json:"provider_name,omitempty"`
+	Subject      string             `json:"subject,omitempty"`
+	LinkedAt     time.Time          `json:"linked_at"`
+	LastLoginAt  *time.Time         `json:"last_login_at,omitempty"`
+}
+
+// AccountLinkCandidateStatus representa o estado de um candidato à ligação
+type AccountLinkCandidateStatus string
+
+// Estados dos candidatos: pending → linked | dismissed | expired
+const (
+	AccountLinkCandidatePending   AccountLinkCandidateStatus = "pending"
+	AccountLinkCandidateLinked    AccountLinkCandidateStatus = "linked"
+	AccountLinkCandidateDismissed AccountLinkCandidateStatus = "dismissed"
+	AccountLinkCandidateExpired   AccountLinkCandidateStatus = "expired"
+)
+
+// AccountLinkCandidate é uma identidade federada desconhecida cujo email verificado corresponde
+// a uma conta local do tenant. O candidato só pode ser ligado pelo próprio usuário, com
+// autenticação recente, antes de expirar
+type AccountLinkCandidate struct {
+	ID         uuid.UUID                  `json:"id"`
+	TenantID   uuid.UUID                  `json:"tenant_id"`
+	UserID     uuid.UUID                  `json:"user_id"`
+	ProviderID uuid.UUID                  `json:"provider_id"`
+	Subject    string                     `json:"subject"`
+	Email      string                     `json:"email"`
+	Status     AccountLinkCandidateStatus `json:"status"`
+	DetectedAt time.Time                  `json:"detected_at"`
+	ExpiresAt  time.Time                  `json:"expires_at"`
+	ResolvedAt *time.Time                 `json:"resolved_at,omitempty"`
+}
+
+// IsOpen indica se o candidato ainda pode ser ligado ou recusado
+func (c *AccountLinkCandidate) IsOpen(now time.Time) bool {
+	return c.Status == AccountLinkCandidatePending && now.Before(c.ExpiresAt)
+}
+
+// AccountLinkAction representa uma operação gravada no registo de auditoria da ligação de contas
+type AccountLinkAction string
+
+// Operações auditadas
+const (
+	AccountLinkActionCandidateDetected  AccountLinkAction = "candidate_detected"
+	AccountLinkActionCandidateDismissed AccountLinkAction = "candidate_dismissed"
+	AccountLinkActionLinked             AccountLinkAction = "linked"
+	AccountLinkActionUnlinked           AccountLinkAction = "unlinked"
+)
+
+// IsValid indica se a operação é conhecida
+func (a AccountLinkAction) IsValid() bool {
+	switch a {
+	case AccountLinkActionCandidateDetected, AccountLinkActionCandidateDismissed,
+		AccountLinkActionLinked, AccountLinkActionUnlinked:
+		return true
+	}
+	return false
+}
+
+// AccountLinkEvent é um registo imutável de uma operação de ligação de contas
+// AuthenticatedAt é o instante da autenticação apresentada nas operações que a exigem
+type AccountLinkEvent struct {
+	ID              uuid.UUID         `json:"id"`
+	TenantID        uuid.UUID         `json:"tenant_id"`
+	UserID          uuid.UUID         `json:"user_id"`
+	ActorID         *uuid.UUID        `json:"actor_id,omitempty"`
+	Action          AccountLinkAction `json:"action"`
+	ProviderID      uuid.UUID         `json:"provider_id"`
+	Subject         string            `json:"subject"`
+	CandidateID     *uuid.UUID        `json:"candidate_id,omitempty"`
+	IdentityID      *uuid.UUID        `json:"identity_id,omitempty"`
+	AuthenticatedAt *time.Time        `json:"authenticated_at,omitempty"`
+	IPAddress       string            `json:"ip_address,omitempty"`
+	OccurredAt      time.Time         `json:"occurred_at"`
+}
+
+// AccountLinkEventFilter filtra o registo de auditoria da ligação de contas
+type AccountLinkEventFilter struct {
+	UserID *uuid.UUID
+	Action AccountLinkAction
+	Since  *time.Time
+	Limit  int
+}
+
+// Erros específicos da ligação de contas
+var (
+	ErrAccountLinkCandidateNotFound = errors.New("candidato à ligação de contas não encontrado")
+	ErrAccountLinkCandidateClosed   = errors.New("candidato à ligação de contas expirado ou já resolvido")
+	ErrLinkedIdentityNotFound       = errors.New("identidade ligada não encontrada")
+	ErrIdentityAlreadyLinked        = errors.New("a identidade federada já está ligada a um usuário")
+	ErrLastLinkedIdentity           = errors.New("o usuário ficaria sem nenhum método de login")
+	ErrReauthenticationRequired     = errors.New("a operação exige autenticação recente")
+	ErrInvalidAccountLinkFilter     = errors.New("filtro do registo de ligação de contas inválido")
+)
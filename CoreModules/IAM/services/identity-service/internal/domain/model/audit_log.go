@@ -0,0 +1,180 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Consulta dos registos de auditoria do tenant (iam.audit_logs) pelas equipas de compliance.
+ * Os registos são apenas lidos: pesquisa em texto livre nos detalhes, filtros por ator, alvo,
+ * tipo de evento, severidade, mercado e período, paginação por cursor e exportação em CSV.
+ */
+
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditSeverity representa a severidade de um registo de auditoria
+type AuditSeverity string
+
+// Severidades dos registos de auditoria, da menor para a maior
+const (
+	AuditSeverityInfo     AuditSeverity = "info"
+	AuditSeverityLow      AuditSeverity = "low"
+	AuditSeverityMedium   AuditSeverity = "medium"
+	AuditSeverityHigh     AuditSeverity = "high"
+	AuditSeverityCritical AuditSeverity = "critical"
+)
+
+// Valid indica se a severidade é conhecida
+func (s AuditSeverity) Valid() bool {
+	switch s {
+	case AuditSeverityInfo, AuditSeverityLow, AuditSeverityMedium, AuditSeverityHigh, AuditSeverityCritical:
+		return true
+	}
+	return false
+}
+
+// Tamanho máximo do texto pesquisado nos detalhes dos registos
+const maxAuditLogQueryLength = 256
+
+// AuditLogEntry representa um registo imutável de auditoria
+type AuditLogEntry struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	EventType string    `json:"event_type"`
+	// Alvo da ação: tipo e identificador da entidade afetada
+	EntityType string    `json:"entity_type"`
+	EntityID   uuid.UUID `json:"entity_id"`
+	// Ator da ação; ausente nas ações do sistema
+	UserID    *uuid.UUID      `json:"user_id,omitempty"`
+	Severity  AuditSeverity   `json:"severity"`
+	Market    string          `json:"market,omitempty"`
+	Details   string          `json:"details,omitempty"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	OldValue  json.RawMessage `json:"old_value,omitempty"`
+	NewValue  json.RawMessage `json:"new_value,omitempty"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditLogCSVHeader são as colunas da exportação CSV dos registos de auditoria
+var AuditLogCSVHeader = []string{
+	"id", "created_at", "event_type", "severity", "market", "user_id", "entity_type", "entity_id",
+	"details", "ip_address", "user_agent", "old_value", "new_value", "metadata",
+}
+
+// CSVRecord retorna os valores do registo pela ordem de AuditLogCSVHeader
+func (e *AuditLogEntry) CSVRecord() []string {
+	userID := ""
+	if e.UserID != nil {
+		userID = e.UserID.String()
+	}
+	return []string{
+		e.ID.String(),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.EventType,
+		string(e.Severity),
+		e.Market,
+		userID,
+		e.EntityType,
+		e.EntityID.String(),
+		e.Details,
+		e.IPAddress,
+		e.UserAgent,
+		string(e.OldValue),
+		string(e.NewValue),
+		string(e.Metadata),
+	}
+}
+
+// AuditLogCursor identifica o último registo de uma página, na ordem (created_at, id) decrescente
+type AuditLogCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode codifica o cursor num valor opaco para os clientes
+func (c AuditLogCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseAuditLogCursor interpreta um cursor devolvido numa página anterior
+func ParseAuditLogCursor(value string) (*AuditLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor inválido", ErrInvalidAuditLogFilter)
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, fmt.Errorf("%w: cursor inválido", ErrInvalidAuditLogFilter)
+	}
+
+	cursor := &AuditLogCursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("%w: cursor inválido", ErrInvalidAuditLogFilter)
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: cursor inválido", ErrInvalidAuditLogFilter)
+	}
+	return cursor, nil
+}
+
+// AuditLogFilter restringe a consulta dos registos de auditoria do tenant
+type AuditLogFilter struct {
+	// Texto livre pesquisado nos detalhes, no tipo de evento e no tipo de alvo (sintaxe websearch)
+	Query   string
+	ActorID *uuid.UUID
+	// Alvo da ação: tipo da entidade e, opcionalmente, o seu identificador
+	TargetType string
+	TargetID   *uuid.UUID
+	// Tipos de evento e severidades aceites; vazio aceita todos
+	EventTypes []string
+	Severities []AuditSeverity
+	Market     string
+	// Intervalo [From, To) do registo
+	From *time.Time
+	To   *time.Time
+	// Registos anteriores ao cursor, na ordem (created_at, id) decrescente
+	Cursor *AuditLogCursor
+	Limit  int
+}
+
+// Validate verifica a coerência do filtro
+func (f AuditLogFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: o início do intervalo deve ser anterior ao fim", ErrInvalidAuditLogFilter)
+	}
+	if len(f.Query) > maxAuditLogQueryLength {
+		return fmt.Errorf("%w: texto de pesquisa com mais de %d caracteres", ErrInvalidAuditLogFilter, maxAuditLogQueryLength)
+	}
+	if f.TargetID != nil && f.TargetType == "" {
+		return fmt.Errorf("%w: o identificador do alvo requer o tipo do alvo", ErrInvalidAuditLogFilter)
+	}
+	for _, severity := range f.Severities {
+		if !severity.Valid() {
+			return fmt.Errorf("%w: severidade %q desconhecida", ErrInvalidAuditLogFilter, severity)
+		}
+	}
+	return nil
+}
+
+// AuditLogPage é uma página de registos de auditoria, do mais recente para o mais antigo
+type AuditLogPage struct {
+	Entries []*AuditLogEntry `json:"entries"`
+	// Cursor da página seguinte; ausente na última página
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Erros específicos da consulta dos registos de auditoria
+var (
+	ErrInvalidAuditLogFilter = errors.New("filtro dos registos de auditoria inválido")
+)
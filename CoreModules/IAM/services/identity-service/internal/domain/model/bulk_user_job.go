@@ -0,0 +1,322 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Operações em massa sobre usuários.
+ * Uma tarefa aplica a mesma operação (desativação, reativação, atribuição ou revogação
+ * de função) a milhares de usuários enviados em NDJSON ou CSV. A execução é assíncrona e
+ * avança por lotes, com o resultado de cada item gravado com o lote; uma tarefa interrompida
+ * continua nos itens pendentes e uma tarefa com falhas pode ser retomada nos itens falhados.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BulkUserOperation representa a operação aplicada a cada usuário da tarefa
+type BulkUserOperation string
+
+// Operações em massa suportadas
+const (
+	// Suspende a conta do usuário; a suspensão é reversível e exige motivo
+	BulkUserOperationDisable BulkUserOperation = "disable"
+	// Reativa a conta de um usuário suspenso ou bloqueado
+	BulkUserOperationEnable BulkUserOperation = "enable"
+	// Atribui a função ao usuário
+	BulkUserOperationAssignRole BulkUserOperation = "assign_role"
+	// Revoga a função do usuário
+	BulkUserOperationRevokeRole BulkUserOperation = "revoke_role"
+)
+
+// IsValid indica se a operação é conhecida
+func (o BulkUserOperation) IsValid() bool {
+	switch o {
+	case BulkUserOperationDisable, BulkUserOperationEnable, BulkUserOperationAssignRole, BulkUserOperationRevokeRole:
+		return true
+	}
+	return false
+}
+
+// RequiresRole indica se cada item da operação precisa de uma função
+func (o BulkUserOperation) RequiresRole() bool {
+	return o == BulkUserOperationAssignRole || o == BulkUserOperationRevokeRole
+}
+
+// TargetStatus retorna o estado da conta pretendido pelas operações de ciclo de vida
+func (o BulkUserOperation) TargetStatus() (UserStatus, bool) {
+	switch o {
+	case BulkUserOperationDisable:
+		return UserStatusSuspended, true
+	case BulkUserOperationEnable:
+		return UserStatusActive, true
+	}
+	return "", false
+}
+
+// AuditEventType retorna o tipo do registo de auditoria gravado por cada usuário afetado
+func (o BulkUserOperation) AuditEventType() string {
+	return "USER_BULK_" + strings.ToUpper(string(o))
+}
+
+// AuditSeverity retorna a severidade do registo de auditoria de cada usuário afetado
+func (o BulkUserOperation) AuditSeverity() AuditSeverity {
+	if o == BulkUserOperationDisable || o == BulkUserOperationRevokeRole {
+		return AuditSeverityMedium
+	}
+	return AuditSeverityLow
+}
+
+// BulkUserJobStatus representa o estado de uma tarefa
+type BulkUserJobStatus string
+
+// Estados de uma tarefa
+const (
+	BulkUserJobStatusPending   BulkUserJobStatus = "pending"
+	BulkUserJobStatusRunning   BulkUserJobStatus = "running"
+	BulkUserJobStatusCompleted BulkUserJobStatus = "completed"
+	// Todos os itens foram processados e pelo menos um falhou
+	BulkUserJobStatusCompletedWithErrors BulkUserJobStatus = "completed_with_errors"
+	// A execução foi interrompida por um erro que não é de um item
+	BulkUserJobStatusFailed BulkUserJobStatus = "failed"
+)
+
+// BulkUserItemStatus representa o resultado de um item
+type BulkUserItemStatus string
+
+// Resultados de um item
+const (
+	BulkUserItemStatusPending   BulkUserItemStatus = "pending"
+	BulkUserItemStatusSucceeded BulkUserItemStatus = "succeeded"
+	// O usuário já estava no estado pretendido; nada foi alterado
+	BulkUserItemStatusSkipped BulkUserItemStatus = "skipped"
+	BulkUserItemStatusFailed  BulkUserItemStatus = "failed"
+)
+
+// IsValid indica se o resultado é conhecido
+func (s BulkUserItemStatus) IsValid() bool {
+	switch s {
+	case BulkUserItemStatusPending, BulkUserItemStatusSucceeded, BulkUserItemStatusSkipped, BulkUserItemStatusFailed:
+		return true
+	}
+	return false
+}
+
+// Códigos do resultado dos itens ignorados ou falhados
+const (
+	BulkUserItemCodeAlreadyApplied    = "already_applied"
+	BulkUserItemCodeUserNotFound      = "user_not_found"
+	BulkUserItemCodeRoleNotFound      = "role_not_found"
+	BulkUserItemCodeInvalidTransition = "invalid_transition"
+	BulkUserItemCodeBoundaryExceeded  = "permission_boundary_exceeded"
+	BulkUserItemCodeError             = "error"
+)
+
+// Erros das operações em massa sobre usuários
+var (
+	ErrBulkUserJobNotFound = errors.New("tarefa de operação em massa não encontrada")
+	ErrInvalidBulkUserJob  = errors.New("tarefa de operação em massa inválida")
+	ErrBulkUserJobConflict = errors.New("tarefa de operação em massa alterada concorrentemente")
+)
+
+// BulkUserJobItem representa um usuário da tarefa e o resultado da operação sobre ele
+type BulkUserJobItem struct {
+	JobID    uuid.UUID `json:"job_id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	// Linha do item no conteúdo enviado, a partir de 1
+	Line      int                `json:"line"`
+	UserID    uuid.UUID          `json:"user_id"`
+	RoleID    *uuid.UUID         `json:"role_id,omitempty"`
+	Reason    string             `json:"reason,omitempty"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty"`
+	Status    BulkUserItemStatus `json:"status"`
+	// Código e descrição do resultado dos itens ignorados ou falhados
+	Code        string     `json:"code,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Attempts    int        `json:"attempts"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+// validate verifica que o item tem os dados exigidos pela operação
+func (i *BulkUserJobItem) validate(operation BulkUserOperation) error {
+	if i.UserID == uuid.Nil {
+		return fmt.Errorf("%w: linha %d sem user_id", ErrInvalidBulkUserJob, i.Line)
+	}
+	if operation.RequiresRole() && (i.RoleID == nil || *i.RoleID == uuid.Nil) {
+		return fmt.Errorf("%w: linha %d sem role_id", ErrInvalidBulkUserJob, i.Line)
+	}
+	if !operation.RequiresRole() && i.RoleID != nil {
+		return fmt.Errorf("%w: linha %d com função numa operação %s", ErrInvalidBulkUserJob, i.Line, operation)
+	}
+	if i.ExpiresAt != nil && operation != BulkUserOperationAssignRole {
+		return fmt.Errorf("%w: linha %d com expiração numa operação %s", ErrInvalidBulkUserJob, i.Line, operation)
+	}
+	if operation == BulkUserOperationDisable && strings.TrimSpace(i.Reason) == "" {
+		return fmt.Errorf("%w: linha %d sem motivo para a desativação", ErrInvalidBulkUserJob, i.Line)
+	}
+	return nil
+}
+
+// Succeed regista a aplicação da operação ao usuário
+func (i *BulkUserJobItem) Succeed(now time.Time) {
+	i.finish(BulkUserItemStatusSucceeded, "", "", now)
+}
+
+// Skip regista que o usuário já estava no estado pretendido
+func (i *BulkUserJobItem) Skip(code, message string, now time.Time) {
+	i.finish(BulkUserItemStatusSkipped, code, message, now)
+}
+
+// Fail regista a falha da operação sobre o usuário; o item pode ser retomado com a tarefa
+func (i *BulkUserJobItem) Fail(code, message string, now time.Time) {
+	i.finish(BulkUserItemStatusFailed, code, message, now)
+}
+
+// finish grava o resultado do item
+func (i *BulkUserJobItem) finish(status BulkUserItemStatus, code, message string, now time.Time) {
+	processedAt := now
+	i.Status = status
+	i.Code = code
+	i.Message = message
+	i.Attempts++
+	i.ProcessedAt = &processedAt
+}
+
+// BulkUserJob representa a aplicação de uma operação a um conjunto de usuários do tenant
+type BulkUserJob struct {
+	ID        uuid.UUID         `json:"id"`
+	TenantID  uuid.UUID         `json:"tenant_id"`
+	Operation BulkUserOperation `json:"operation"`
+	Status    BulkUserJobStatus `json:"status"`
+	// Contadores dos itens; Processed é a soma dos itens com resultado
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Succeeded   int        `json:"succeeded"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	Error       string     `json:"error,omitempty"`
+	RequestedBy uuid.UUID  `json:"requested_by"`
+	Attempts    int        `json:"attempts"`
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// NewBulkUserJob cria uma tarefa pendente com os itens enviados
+// Os itens são validados pela operação; um usuário só pode constar uma vez da tarefa
+func NewBulkUserJob(
+	tenantID, requestedBy uuid.UUID,
+	operation BulkUserOperation,
+	items []*BulkUserJobItem,
+	now time.Time,
+) (*BulkUserJob, error) {
+	if tenantID == uuid.Nil {
+		return nil, fmt.Errorf("%w: tenant obrigatório", ErrInvalidBulkUserJob)
+	}
+	if !operation.IsValid() {
+		return nil, fmt.Errorf("%w: operação desconhecida %q", ErrInvalidBulkUserJob, operation)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: nenhum usuário indicado", ErrInvalidBulkUserJob)
+	}
+
+	job := &BulkUserJob{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Operation:   operation,
+		Status:      BulkUserJobStatusPending,
+		Total:       len(items),
+		RequestedBy: requestedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	lines := make(map[uuid.UUID]int, len(items))
+	for _, item := range items {
+		if err := item.validate(operation); err != nil {
+			return nil, err
+		}
+		if line, ok := lines[item.UserID]; ok {
+			return nil, fmt.Errorf("%w: linha %d repete o usuário da linha %d", ErrInvalidBulkUserJob, item.Line, line)
+		}
+		lines[item.UserID] = item.Line
+
+		item.JobID = job.ID
+		item.TenantID = tenantID
+		item.Status = BulkUserItemStatusPending
+	}
+
+	return job, nil
+}
+
+// IsActive indica se a tarefa aguarda ou está em execução
+func (j *BulkUserJob) IsActive() bool {
+	return j.Status == BulkUserJobStatusPending || j.Status == BulkUserJobStatusRunning
+}
+
+// Start marca o início de uma passagem de execução
+// Uma tarefa em execução pode ser retomada após a interrupção do processo que a executava
+func (j *BulkUserJob) Start(now time.Time) error {
+	if !j.IsActive() {
+		return fmt.Errorf("%w: estado atual %s", ErrInvalidBulkUserJob, j.Status)
+	}
+	j.Status = BulkUserJobStatusRunning
+	j.Attempts++
+	j.UpdatedAt = now
+	return nil
+}
+
+// Record contabiliza o resultado de um item processado
+func (j *BulkUserJob) Record(item *BulkUserJobItem) {
+	j.Processed++
+	switch item.Status {
+	case BulkUserItemStatusSucceeded:
+		j.Succeeded++
+	case BulkUserItemStatusSkipped:
+		j.Skipped++
+	case BulkUserItemStatusFailed:
+		j.Failed++
+	}
+}
+
+// Complete conclui a tarefa depois de processados todos os itens
+func (j *BulkUserJob) Complete(now time.Time) {
+	completedAt := now
+	j.Status = BulkUserJobStatusCompleted
+	if j.Failed > 0 {
+		j.Status = BulkUserJobStatusCompletedWithErrors
+	}
+	j.Error = ""
+	j.UpdatedAt = now
+	j.CompletedAt = &completedAt
+}
+
+// Fail regista a falha da passagem; a tarefa pode ser retomada a partir dos itens pendentes
+func (j *BulkUserJob) Fail(reason string, now time.Time) {
+	j.Status = BulkUserJobStatusFailed
+	j.Error = reason
+	j.UpdatedAt = now
+}
+
+// Resume devolve à fila uma tarefa falhada ou concluída com erros
+// Os itens falhados voltam a estar pendentes e deixam de contar como processados
+func (j *BulkUserJob) Resume(now time.Time) error {
+	if j.Status != BulkUserJobStatusFailed && j.Status != BulkUserJobStatusCompletedWithErrors {
+		return fmt.Errorf("%w: apenas tarefas falhadas ou concluídas com erros podem ser retomadas, estado atual %s",
+			ErrInvalidBulkUserJob, j.Status)
+	}
+	j.Processed -= j.Failed
+	j.Failed = 0
+	j.Status = BulkUserJobStatusPending
+	j.Error = ""
+	j.UpdatedAt = now
+	j.CompletedAt = nil
+	return nil
+}
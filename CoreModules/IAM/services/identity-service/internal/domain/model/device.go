@@ -0,0 +1,240 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Registo dos dispositivos dos usuários: os dispositivos vistos nos logins ficam registados por
+ * usuário, podem ser renomeados, revogados e marcados como de confiança dentro dos limites da
+ * política do tenant, para que os fluxos de MFA dispensem o segundo fator nesses dispositivos.
+ * O mesmo dispositivo usado por várias contas do tenant é um sinal de fraude.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// DeviceStatus representa o estado de um dispositivo registado
+type DeviceStatus string
+
+// Estados de um dispositivo
+const (
+	DeviceStatusActive DeviceStatus = "active"
+	// DeviceStatusRevoked indica um dispositivo retirado pelo usuário ou por um administrador
+	// Um login posterior a partir dele volta a registá-lo como dispositivo novo, sem confiança
+	DeviceStatusRevoked DeviceStatus = "revoked"
+)
+
+// Motivos da decisão de confiança de um dispositivo
+const (
+	DeviceTrustReasonTrusted      = "trusted"
+	DeviceTrustReasonPolicyOff    = "policy_disabled"
+	DeviceTrustReasonUnidentified = "unidentified_device" // Login sem identificador do dispositivo
+	DeviceTrustReasonUnknown      = "unknown_device"
+	DeviceTrustReasonRevoked      = "revoked"
+	DeviceTrustReasonNotTrusted   = "not_trusted"
+	DeviceTrustReasonExpired      = "trust_expired"
+	DeviceTrustReasonShared       = "shared_device" // Dispositivo usado por várias contas do tenant
+)
+
+// Limites da política de dispositivos
+const (
+	MaxDeviceTrustDays       = 90
+	MaxTrustedDevicesPerUser = 20
+	MinDeviceReuseThreshold  = 2
+	MaxDeviceReuseThreshold  = 100
+	MaxDeviceNameSize        = 100
+)
+
+// Valores da política de um tenant que ainda não a configurou
+const (
+	DefaultDeviceTrustDays      = 30
+	DefaultMaxTrustedDevices    = 5
+	DefaultDeviceReuseThreshold = 3
+)
+
+// Erros do registo de dispositivos
+var (
+	ErrDeviceNotFound       = errors.New("dispositivo não encontrado")
+	ErrInvalidDevice        = errors.New("dispositivo inválido")
+	ErrInvalidDevicePolicy  = errors.New("política de dispositivos inválida")
+	ErrDeviceRevoked        = errors.New("dispositivo revogado")
+	ErrDeviceTrustDisabled  = errors.New("a confiança em dispositivos está desativada no tenant")
+	ErrTrustedDeviceLimit   = errors.New("limite de dispositivos de confiança do usuário atingido")
+	ErrDeviceTrustForbidden = errors.New("o dispositivo não pode ser marcado como de confiança")
+)
+
+// DevicePolicy representa os limites da confiança em dispositivos de um tenant
+type DevicePolicy struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	// TrustEnabled permite marcar dispositivos como de confiança para dispensar o segundo fator
+	TrustEnabled bool `json:"trust_enabled"`
+	// TrustDays é a validade da confiança; depois dela o usuário volta a fazer MFA e a confiar no dispositivo
+	TrustDays int `json:"trust_days"`
+	// MaxTrustedDevices é o número máximo de dispositivos de confiança válidos por usuário
+	MaxTrustedDevices int `json:"max_trusted_devices"`
+	// ReuseThreshold é o número de contas do tenant a partir do qual um dispositivo partilhado é sinalizado
+	ReuseThreshold int       `json:"reuse_threshold"`
+	UpdatedBy      uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultDevicePolicy retorna a política de um tenant que ainda não a configurou
+// A confiança em dispositivos fica desativada; o registo e a deteção de partilha funcionam sempre
+func DefaultDevicePolicy(tenantID uuid.UUID) *DevicePolicy {
+	return &DevicePolicy{
+		TenantID:          tenantID,
+		TrustEnabled:      false,
+		TrustDays:         DefaultDeviceTrustDays,
+		MaxTrustedDevices: DefaultMaxTrustedDevices,
+		ReuseThreshold:    DefaultDeviceReuseThreshold,
+	}
+}
+
+// Validate verifica os limites da política
+func (p *DevicePolicy) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if p.TrustDays < 1 || p.TrustDays > MaxDeviceTrustDays {
+		return fmt.Errorf("%w: a validade da confiança deve estar entre 1 e %d dias", ErrInvalidDevicePolicy, MaxDeviceTrustDays)
+	}
+	if p.MaxTrustedDevices < 1 || p.MaxTrustedDevices > MaxTrustedDevicesPerUser {
+		return fmt.Errorf("%w: o máximo de dispositivos de confiança deve estar entre 1 e %d", ErrInvalidDevicePolicy, MaxTrustedDevicesPerUser)
+	}
+	if p.ReuseThreshold < MinDeviceReuseThreshold || p.ReuseThreshold > MaxDeviceReuseThreshold {
+		return fmt.Errorf("%w: o limiar de partilha deve estar entre %d e %d contas", ErrInvalidDevicePolicy, MinDeviceReuseThreshold, MaxDeviceReuseThreshold)
+	}
+	return nil
+}
+
+// TrustDuration retorna a validade da confiança num dispositivo
+func (p *DevicePolicy) TrustDuration() time.Duration {
+	return time.Duration(p.TrustDays) * 24 * time.Hour
+}
+
+// Device representa um dispositivo visto nos logins de um usuário
+// Apenas o HMAC do identificador do dispositivo é gravado
+type Device struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	// Fingerprint identifica o dispositivo nos logins do usuário
+	Fingerprint string `json:"-"`
+	// ReuseKey identifica o dispositivo em todo o tenant, para detetar a partilha entre contas
+	// Vazio nos dispositivos reconhecidos apenas pelo agente, que muitas contas partilham sem fraude
+	ReuseKey      string       `json:"-"`
+	Identified    bool         `json:"identified"`
+	UserAgent     string       `json:"user_agent,omitempty"`
+	LastIPAddress string       `json:"last_ip_address,omitempty"`
+	LastCountry   string       `json:"last_country,omitempty"`
+	LastMethod    string       `json:"last_method,omitempty"`
+	Status        DeviceStatus `json:"status"`
+	LoginCount    int          `json:"login_count"`
+	FirstSeenAt   time.Time    `json:"first_seen_at"`
+	LastSeenAt    time.Time    `json:"last_seen_at"`
+	// TrustedAt e TrustExpiresAt delimitam a confiança no dispositivo; nulos quando não é de confiança
+	TrustedAt      *time.Time `json:"trusted_at,omitempty"`
+	TrustExpiresAt *time.Time `json:"trust_expires_at,omitempty"`
+	TrustedBy      *uuid.UUID `json:"trusted_by,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy      *uuid.UUID `json:"revoked_by,omitempty"`
+	// ReuseDetectedAt indica quando o dispositivo foi sinalizado por ser usado por várias contas
+	ReuseDetectedAt *time.Time `json:"reuse_detected_at,omitempty"`
+}
+
+// IsTrusted indica se o dispositivo está ativo e com a confiança válida no instante indicado
+func (d *Device) IsTrusted(now time.Time) bool {
+	return d.Status == DeviceStatusActive && d.TrustExpiresAt != nil && now.Before(*d.TrustExpiresAt)
+}
+
+// ClearTrust retira a confiança no dispositivo
+func (d *Device) ClearTrust() {
+	d.TrustedAt = nil
+	d.TrustExpiresAt = nil
+	d.TrustedBy = nil
+}
+
+// NormalizeDeviceName limpa o nome dado a um dispositivo e verifica o tamanho
+func NormalizeDeviceName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > MaxDeviceNameSize {
+		return "", fmt.Errorf("%w: o nome é obrigatório e tem no máximo %d caracteres", ErrInvalidDevice, MaxDeviceNameSize)
+	}
+	return name, nil
+}
+
+// deviceBrowsers e deviceSystems identificam o navegador e o sistema pelo agente, pela ordem de
+// verificação; os agentes de vários navegadores indicam também os navegadores em que se baseiam
+var (
+	deviceBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	}
+	deviceSystems = []struct{ token, name string }{
+		{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+)
+
+// DefaultDeviceName retorna o nome de um dispositivo novo a partir do agente (ex.: "Firefox (Windows)")
+func DefaultDeviceName(userAgent string) string {
+	browser, system := "", ""
+	for _, candidate := range deviceBrowsers {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+	for _, candidate := range deviceSystems {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " (" + system + ")"
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Dispositivo desconhecido"
+}
+
+// DeviceTrustDecision indica se um login pode dispensar o segundo fator por vir de um dispositivo de confiança
+type DeviceTrustDecision struct {
+	Trusted        bool       `json:"trusted"`
+	Reason         string     `json:"reason"`
+	DeviceID       *uuid.UUID `json:"device_id,omitempty"`
+	TrustExpiresAt *time.Time `json:"trust_expires_at,omitempty"`
+}
+
+// NewDeviceFingerprintReuseIncident cria o incidente de segurança, de severidade média, de um
+// dispositivo usado por várias contas do tenant; as contas envolvidas ficam nas evidências
+func NewDeviceFingerprintReuseIncident(device *Device, accounts []uuid.UUID, now time.Time) (*SecurityIncident, error) {
+	summary := fmt.Sprintf("Dispositivo %q usado por %d contas do tenant", device.Name, len(accounts))
+	evidence := []SecurityIncidentEvidence{{
+		EventType:  "device.fingerprint_reused",
+		OccurredAt: device.LastSeenAt,
+		SubjectIDs: accounts,
+	}}
+	actorID := device.UserID
+	return NewSecurityIncident(
+		device.TenantID,
+		SecurityIncidentRuleDeviceFingerprintReuse,
+		SecurityIncidentSeverityMedium,
+		&actorID,
+		summary,
+		evidence,
+		now,
+	)
+}
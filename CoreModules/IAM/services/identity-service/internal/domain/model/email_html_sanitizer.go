@@ -0,0 +1,203 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Sanitização do HTML dos modelos de email por lista de elementos e atributos permitidos.
+ * Os modelos são escritos pelos administradores dos tenants e enviados aos seus usuários:
+ * scripts, formulários, conteúdo embebido, handlers de eventos e URLs javascript: ou data:
+ * são removidos antes de o modelo ser compilado. As ações {{...}} são preservadas.
+ */
+
+package model
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// emailAllowedElements são os elementos mantidos no HTML dos emails
+var emailAllowedElements = map[string]bool{
+	"html": true, "head": true, "title": true, "body": true,
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true, "center": true, "code": true,
+	"div": true, "em": true, "font": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "hr": true, "i": true, "img": true, "li": true, "ol": true, "p": true, "pre": true,
+	"small": true, "span": true, "strong": true, "sub": true, "sup": true, "table": true, "tbody": true,
+	"td": true, "tfoot": true, "th": true, "thead": true, "tr": true, "u": true, "ul": true,
+}
+
+// emailDroppedElements são removidos com o conteúdo; os restantes elementos não permitidos
+// são removidos mantendo o conteúdo
+var emailDroppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "noscript": true, "noembed": true, "noframes": true, "template": true,
+	"svg": true, "math": true, "textarea": true, "select": true, "xmp": true, "plaintext": true,
+}
+
+// emailAllowedAttributes são os atributos permitidos em todos os elementos
+var emailAllowedAttributes = map[string]bool{
+	"align": true, "bgcolor": true, "border": true, "cellpadding": true, "cellspacing": true,
+	"class": true, "color": true, "dir": true, "face": true, "height": true, "lang": true,
+	"role": true, "size": true, "style": true, "title": true, "valign": true, "width": true,
+}
+
+// emailElementAttributes são os atributos permitidos apenas em alguns elementos
+var emailElementAttributes = map[string]map[string]bool{
+	"a":   {"href": true, "name": true},
+	"img": {"src": true, "alt": true},
+	"td":  {"colspan": true, "rowspan": true},
+	"th":  {"colspan": true, "rowspan": true},
+}
+
+// emailURLSchemes são os esquemas permitidos nos endereços escritos no modelo
+var emailURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// emailForbiddenStyles são os trechos de CSS que carregam recursos ou executam código
+var emailForbiddenStyles = []string{"expression(", "url(", "javascript:", "vbscript:", "behavior:", "-moz-binding", "@import", "/*"}
+
+// emailAttributeEscaper escapa os valores dos atributos reescritos sem alterar as ações {{...}}
+var emailAttributeEscaper = strings.NewReplacer("&", "&amp;", `"`, "&#34;")
+
+// SanitizeEmailHTML remove do HTML de um modelo de email os elementos e os atributos que não
+// estão na lista permitida, os comentários e os endereços com esquemas não permitidos
+// O texto é mantido como foi escrito, para que as ações {{...}} continuem válidas
+func SanitizeEmailHTML(source string) string {
+	var out strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(source))
+	// Elemento removido com o conteúdo e a profundidade dos elementos com o mesmo nome
+	skipping, depth := "", 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				return ""
+			}
+			return out.String()
+		}
+
+		switch tokenType {
+		case html.TextToken:
+			if skipping == "" {
+				out.Write(tokenizer.Raw())
+			}
+
+		case html.DoctypeToken:
+			if skipping == "" {
+				out.WriteString("<!DOCTYPE html>")
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttributes := tokenizer.TagName()
+			tag := string(name)
+			if skipping != "" {
+				if tag == skipping && tokenType == html.StartTagToken {
+					depth++
+				}
+				continue
+			}
+			if emailDroppedElements[tag] {
+				if tokenType == html.StartTagToken {
+					skipping, depth = tag, 1
+				}
+				continue
+			}
+			if !emailAllowedElements[tag] {
+				continue
+			}
+
+			out.WriteString("<" + tag)
+			for hasAttributes {
+				var key, value []byte
+				key, value, hasAttributes = tokenizer.TagAttr()
+				attribute := string(key)
+				if !emailAllowedAttributes[attribute] && !emailElementAttributes[tag][attribute] {
+					continue
+				}
+				if (attribute == "href" || attribute == "src") && !emailSafeURL(string(value)) {
+					continue
+				}
+				if attribute == "style" && !emailSafeStyle(string(value)) {
+					continue
+				}
+				out.WriteString(" " + attribute + `="` + emailAttributeEscaper.Replace(string(value)) + `"`)
+			}
+			if tokenType == html.SelfClosingTagToken {
+				out.WriteString(" /")
+			}
+			out.WriteString(">")
+
+		case html.CommentToken:
+			// Os comentários são sempre removidos, incluindo os comentários condicionais
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if skipping != "" {
+				if tag == skipping {
+					if depth--; depth == 0 {
+						skipping = ""
+					}
+				}
+				continue
+			}
+			if emailAllowedElements[tag] {
+				out.WriteString("</" + tag + ">")
+			}
+		}
+	}
+}
+
+// emailSafeURL indica se o endereço de um atributo href ou src é permitido
+//
+// Os endereços escritos no modelo têm de ser http, https ou mailto, ou relativos. Uma ação no
+// início do endereço é aceite porque o html/template filtra o esquema do valor produzido, desde
+// que o resto do endereço seja um caminho, uma query ou um fragmento; as ações depois de um texto
+// sem esquema não são filtradas e podiam formar um esquema javascript:
+func emailSafeURL(value string) bool {
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+	if compact == "" {
+		return true
+	}
+
+	if strings.HasPrefix(compact, "{{") {
+		end := strings.Index(compact, "}}")
+		if end < 0 {
+			return false
+		}
+		rest := compact[end+2:]
+		return rest == "" || strings.ContainsRune("/?#", rune(rest[0]))
+	}
+
+	prefix := compact
+	if i := strings.Index(compact, "{{"); i >= 0 {
+		prefix = compact[:i]
+	}
+	if i := strings.IndexAny(prefix, ":/?#"); i >= 0 && prefix[i] == ':' {
+		return emailURLSchemes[strings.ToLower(prefix[:i])]
+	}
+	return strings.ContainsRune("/?#", rune(prefix[0]))
+}
+
+// emailSafeStyle indica se o atributo style não carrega recursos nem executa código
+func emailSafeStyle(value string) bool {
+	compact := strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '\\' {
+			return -1
+		}
+		return r
+	}, value))
+	for _, forbidden := range emailForbiddenStyles {
+		if strings.Contains(compact, forbidden) {
+			return false
+		}
+	}
+	return true
+}
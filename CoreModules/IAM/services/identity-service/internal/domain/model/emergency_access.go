@@ -0,0 +1,381 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Contas de emergência (break-glass): contas pré-provisionadas com acesso elevado, seladas
+ * até serem ativadas com controlo duplo (pedido e aprovação por pessoas diferentes), com
+ * referência obrigatória ao incidente e duração limitada. Cada pedido feito pela conta durante
+ * a ativação é gravado num registo encadeado por hashes; as ativações terminadas são revistas
+ * por uma terceira pessoa.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmergencyActivationStatus representa o estado de uma ativação de conta de emergência
+type EmergencyActivationStatus string
+
+// Estados das ativações: pending_approval → active → expired | closed → reviewed,
+// ou pending_approval → rejected
+const (
+	EmergencyActivationPending  EmergencyActivationStatus = "pending_approval"
+	EmergencyActivationActive   EmergencyActivationStatus = "active"
+	EmergencyActivationRejected EmergencyActivationStatus = "rejected"
+	EmergencyActivationExpired  EmergencyActivationStatus = "expired"
+	EmergencyActivationClosed   EmergencyActivationStatus = "closed"
+	EmergencyActivationReviewed EmergencyActivationStatus = "reviewed"
+)
+
+// IsValid indica se o estado é conhecido
+func (s EmergencyActivationStatus) IsValid() bool {
+	switch s {
+	case EmergencyActivationPending, EmergencyActivationActive, EmergencyActivationRejected,
+		EmergencyActivationExpired, EmergencyActivationClosed, EmergencyActivationReviewed:
+		return true
+	}
+	return false
+}
+
+// AwaitingReview indica se a ativação terminou e ainda não foi revista
+func (s EmergencyActivationStatus) AwaitingReview() bool {
+	return s == EmergencyActivationExpired || s == EmergencyActivationClosed
+}
+
+// EmergencyReviewOutcome representa a conclusão da revisão de uma ativação
+type EmergencyReviewOutcome string
+
+// Conclusões da revisão
+const (
+	EmergencyReviewJustified   EmergencyReviewOutcome = "justified"
+	EmergencyReviewUnjustified EmergencyReviewOutcome = "unjustified"
+)
+
+// IsValid indica se a conclusão é conhecida
+func (o EmergencyReviewOutcome) IsValid() bool {
+	return o == EmergencyReviewJustified || o == EmergencyReviewUnjustified
+}
+
+// Motivos de recusa de um pedido de uma conta de emergência
+const (
+	EmergencyDenyReasonSealed          = "emergency_account_sealed"
+	EmergencyDenyReasonRecordingFailed = "emergency_recording_failed"
+)
+
+// Motivos do fim automático de uma ativação
+const (
+	EmergencyEndReasonExpired         = "prazo da ativação esgotado"
+	EmergencyEndReasonApprovalTimeout = "pedido não aprovado dentro do prazo"
+)
+
+// Limites das contas de emergência
+const (
+	MaxEmergencyAccountNameSize   = 100
+	MaxEmergencyIncidentRefSize   = 100
+	MinEmergencyJustificationSize = MinBreakGlassJustificationSize
+	MinEmergencyReviewNotesSize   = 20
+)
+
+// EmergencyActionGenesisHash é o hash anterior do primeiro registo de cada ativação
+const EmergencyActionGenesisHash = ""
+
+// Erros das contas de emergência
+var (
+	ErrEmergencyAccountNotFound    = errors.New("conta de emergência não encontrada")
+	ErrInvalidEmergencyAccount     = errors.New("conta de emergência inválida")
+	ErrEmergencyAccountConflict    = errors.New("o usuário ou o nome já pertencem a uma conta de emergência do tenant")
+	ErrEmergencyActivationNotFound = errors.New("ativação da conta de emergência não encontrada")
+	ErrInvalidEmergencyActivation  = errors.New("ativação da conta de emergência inválida")
+	ErrEmergencyActivationOpen     = errors.New("a conta de emergência já tem uma ativação pendente ou em vigor")
+	ErrEmergencyActivationState    = errors.New("o estado da ativação da conta de emergência não permite a operação")
+	ErrEmergencyDualControl        = errors.New("a operação exige uma pessoa diferente das que pediram ou aprovaram a ativação")
+	ErrEmergencyRecordingFailure   = errors.New("não foi possível gravar a ação da conta de emergência")
+)
+
+// EmergencyAccount representa uma conta de emergência pré-provisionada de um tenant
+// O usuário da conta tem as funções elevadas atribuídas, mas os seus pedidos são recusados
+// enquanto a conta não tiver uma ativação em vigor
+type EmergencyAccount struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate verifica a conta de emergência
+func (a *EmergencyAccount) Validate() error {
+	if a.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if a.UserID == uuid.Nil {
+		return fmt.Errorf("%w: o usuário da conta é obrigatório", ErrInvalidEmergencyAccount)
+	}
+	if a.Name == "" || len([]rune(a.Name)) > MaxEmergencyAccountNameSize {
+		return fmt.Errorf("%w: o nome é obrigatório e tem no máximo %d caracteres",
+			ErrInvalidEmergencyAccount, MaxEmergencyAccountNameSize)
+	}
+	return nil
+}
+
+// EmergencyActivation representa um pedido de ativação de uma conta de emergência e o seu ciclo
+// de vida até à revisão
+type EmergencyActivation struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	AccountID uuid.UUID `json:"account_id"`
+	// UserID identifica o usuário da conta de emergência
+	UserID          uuid.UUID                 `json:"user_id"`
+	IncidentRef     string                    `json:"incident_ref"`
+	Justification   string                    `json:"justification"`
+	DurationSeconds int                       `json:"duration_seconds"`
+	Status          EmergencyActivationStatus `json:"status"`
+	RequestedBy     uuid.UUID                 `json:"requested_by"`
+	RequestedAt     time.Time                 `json:"requested_at"`
+	// DecidedBy fica vazio quando o pedido é recusado automaticamente por falta de aprovação
+	DecidedBy    *uuid.UUID `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	DecisionNote string     `json:"decision_note,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// EndedBy fica vazio quando a ativação expira automaticamente
+	EndedAt       *time.Time             `json:"ended_at,omitempty"`
+	EndedBy       *uuid.UUID             `json:"ended_by,omitempty"`
+	EndReason     string                 `json:"end_reason,omitempty"`
+	ReviewedBy    *uuid.UUID             `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time             `json:"reviewed_at,omitempty"`
+	ReviewOutcome EmergencyReviewOutcome `json:"review_outcome,omitempty"`
+	ReviewNotes   string                 `json:"review_notes,omitempty"`
+}
+
+// Duration retorna a duração pedida para a ativação
+func (a *EmergencyActivation) Duration() time.Duration {
+	return time.Duration(a.DurationSeconds) * time.Second
+}
+
+// IsActive indica se a ativação está em vigor no instante indicado
+func (a *EmergencyActivation) IsActive(now time.Time) bool {
+	return a.Status == EmergencyActivationActive && a.ExpiresAt != nil && now.Before(*a.ExpiresAt)
+}
+
+// Validate verifica um novo pedido de ativação
+func (a *EmergencyActivation) Validate() error {
+	if a.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if a.AccountID == uuid.Nil || a.UserID == uuid.Nil || a.RequestedBy == uuid.Nil {
+		return fmt.Errorf("%w: a conta e o requerente são obrigatórios", ErrInvalidEmergencyActivation)
+	}
+	if a.RequestedBy == a.UserID {
+		return fmt.Errorf("%w: a conta de emergência não pode pedir a sua própria ativação", ErrEmergencyDualControl)
+	}
+	if a.IncidentRef == "" || len([]rune(a.IncidentRef)) > MaxEmergencyIncidentRefSize {
+		return fmt.Errorf("%w: a referência do incidente é obrigatória e tem no máximo %d caracteres",
+			ErrInvalidEmergencyActivation, MaxEmergencyIncidentRefSize)
+	}
+	if len([]rune(a.Justification)) < MinEmergencyJustificationSize {
+		return fmt.Errorf("%w: a justificação deve ter pelo menos %d caracteres",
+			ErrInvalidEmergencyActivation, MinEmergencyJustificationSize)
+	}
+	if a.DurationSeconds <= 0 {
+		return fmt.Errorf("%w: a duração deve ser positiva", ErrInvalidEmergencyActivation)
+	}
+	return nil
+}
+
+// Approve aprova um pedido pendente; a duração conta a partir da aprovação
+// O aprovador não pode ser o requerente nem o usuário da conta de emergência
+func (a *EmergencyActivation) Approve(approverID uuid.UUID, now time.Time) error {
+	if a.Status != EmergencyActivationPending {
+		return ErrEmergencyActivationState
+	}
+	if approverID == uuid.Nil || approverID == a.RequestedBy || approverID == a.UserID {
+		return ErrEmergencyDualControl
+	}
+	expiresAt := now.Add(a.Duration())
+	a.Status = EmergencyActivationActive
+	a.DecidedBy = &approverID
+	a.DecidedAt = &now
+	a.ExpiresAt = &expiresAt
+	return nil
+}
+
+// Reject recusa um pedido pendente; sem responsável a recusa é automática
+func (a *EmergencyActivation) Reject(actorID *uuid.UUID, note string, now time.Time) error {
+	if a.Status != EmergencyActivationPending {
+		return ErrEmergencyActivationState
+	}
+	a.Status = EmergencyActivationRejected
+	a.DecidedBy = actorID
+	a.DecidedAt = &now
+	a.DecisionNote = note
+	return nil
+}
+
+// End termina uma ativação em vigor: closed quando terminada por uma pessoa,
+// expired quando terminada automaticamente
+func (a *EmergencyActivation) End(actorID *uuid.UUID, reason string, now time.Time) error {
+	if a.Status != EmergencyActivationActive {
+		return ErrEmergencyActivationState
+	}
+	a.Status = EmergencyActivationClosed
+	if actorID == nil {
+		a.Status = EmergencyActivationExpired
+	}
+	if a.ExpiresAt != nil && a.ExpiresAt.Before(now) {
+		now = *a.ExpiresAt
+	}
+	a.EndedAt = &now
+	a.EndedBy = actorID
+	a.EndReason = reason
+	return nil
+}
+
+// Review regista a revisão de uma ativação terminada
+// O revisor não pode ser o requerente, o aprovador nem o usuário da conta de emergência, e
+// uma ativação considerada injustificada exige notas
+func (a *EmergencyActivation) Review(reviewerID uuid.UUID, outcome EmergencyReviewOutcome, notes string, now time.Time) error {
+	if !a.Status.AwaitingReview() {
+		return ErrEmergencyActivationState
+	}
+	if reviewerID == uuid.Nil || reviewerID == a.RequestedBy || reviewerID == a.UserID ||
+		(a.DecidedBy != nil && reviewerID == *a.DecidedBy) {
+		return ErrEmergencyDualControl
+	}
+	if !outcome.IsValid() {
+		return fmt.Errorf("%w: conclusão da revisão desconhecida %q", ErrInvalidEmergencyActivation, outcome)
+	}
+	if outcome == EmergencyReviewUnjustified && len([]rune(notes)) < MinEmergencyReviewNotesSize {
+		return fmt.Errorf("%w: uma ativação injustificada exige notas com pelo menos %d caracteres",
+			ErrInvalidEmergencyActivation, MinEmergencyReviewNotesSize)
+	}
+	a.Status = EmergencyActivationReviewed
+	a.ReviewedBy = &reviewerID
+	a.ReviewedAt = &now
+	a.ReviewOutcome = outcome
+	a.ReviewNotes = notes
+	return nil
+}
+
+// EmergencyAction regista um pedido feito por uma conta de emergência durante uma ativação
+// Os registos de cada ativação formam uma cadeia: o hash de cada um inclui o do anterior
+type EmergencyAction struct {
+	ID           uuid.UUID `json:"id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	ActivationID uuid.UUID `json:"activation_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Sequence     int64     `json:"sequence"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Query        string    `json:"query,omitempty"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	RequestID    string    `json:"request_id,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash"`
+}
+
+// ComputeHash calcula o hash SHA-256 do registo e do hash anterior
+// Cada campo é prefixado pelo seu tamanho para que a concatenação não seja ambígua
+func (a *EmergencyAction) ComputeHash() string {
+	fields := []string{
+		a.PrevHash,
+		strconv.FormatInt(a.Sequence, 10),
+		a.ID.String(),
+		a.TenantID.String(),
+		a.ActivationID.String(),
+		a.UserID.String(),
+		a.Method,
+		a.Path,
+		a.Query,
+		a.IPAddress,
+		a.UserAgent,
+		a.RequestID,
+		a.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	var builder strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&builder, "%d:%s", len(field), field)
+	}
+	sum := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// Chain liga o registo ao anterior da mesma ativação (nil no primeiro) e calcula o seu hash
+// O instante é arredondado ao microssegundo, a precisão com que é gravado
+func (a *EmergencyAction) Chain(previous *EmergencyAction) {
+	a.OccurredAt = a.OccurredAt.UTC().Truncate(time.Microsecond)
+	a.Sequence = 1
+	a.PrevHash = EmergencyActionGenesisHash
+	if previous != nil {
+		a.Sequence = previous.Sequence + 1
+		a.PrevHash = previous.Hash
+	}
+	a.Hash = a.ComputeHash()
+}
+
+// VerifyEmergencyActionChain verifica uma sequência de registos consecutivos de uma ativação,
+// a seguir a previous (nil quando a sequência começa no primeiro registo)
+// Retorna a sequência do primeiro registo inválido, ou zero quando a cadeia está íntegra
+func VerifyEmergencyActionChain(previous *EmergencyAction, actions []*EmergencyAction) int64 {
+	for _, action := range actions {
+		expectedSequence, expectedPrev := int64(1), EmergencyActionGenesisHash
+		if previous != nil {
+			expectedSequence, expectedPrev = previous.Sequence+1, previous.Hash
+		}
+		if action.Sequence != expectedSequence || action.PrevHash != expectedPrev || action.Hash != action.ComputeHash() {
+			if action.Sequence > 0 {
+				return action.Sequence
+			}
+			return expectedSequence
+		}
+		previous = action
+	}
+	return 0
+}
+
+// EmergencyChainVerification representa o resultado da verificação do registo de uma ativação
+type EmergencyChainVerification struct {
+	ActivationID uuid.UUID `json:"activation_id"`
+	Actions      int       `json:"actions"`
+	Valid        bool      `json:"valid"`
+	// BrokenAtSequence identifica o primeiro registo inválido
+	BrokenAtSequence int64     `json:"broken_at_sequence,omitempty"`
+	HeadHash         string    `json:"head_hash,omitempty"`
+	VerifiedAt       time.Time `json:"verified_at"`
+}
+
+// EmergencyAccessRequest descreve um pedido autenticado verificado contra as contas de emergência
+type EmergencyAccessRequest struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// EmergencyAccessDecision representa o resultado da verificação de um pedido
+type EmergencyAccessDecision struct {
+	Allowed bool `json:"allowed"`
+	// EmergencyAccount indica que o usuário é uma conta de emergência
+	EmergencyAccount bool       `json:"emergency_account"`
+	Reason           string     `json:"reason,omitempty"`
+	ActivationID     *uuid.UUID `json:"activation_id,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,126 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Aceitação dos termos de serviço, das políticas de privacidade e dos consentimentos por tenant.
+ * Cada documento é publicado numa versão com o hash do conteúdo; a aceitação grava o instante,
+ * a versão e o hash aceite, servindo de prova perante a LGPD e o RGPD. Os documentos obrigatórios
+ * em vigor podem bloquear o login até serem aceites.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LegalDocumentType representa o tipo de documento legal
+type LegalDocumentType string
+
+// Tipos de documentos legais
+const (
+	LegalDocumentTermsOfService LegalDocumentType = "terms_of_service"
+	LegalDocumentPrivacyPolicy  LegalDocumentType = "privacy_policy"
+	LegalDocumentConsent        LegalDocumentType = "consent"
+)
+
+// IsValid indica se o tipo de documento é conhecido
+func (t LegalDocumentType) IsValid() bool {
+	switch t {
+	case LegalDocumentTermsOfService, LegalDocumentPrivacyPolicy, LegalDocumentConsent:
+		return true
+	}
+	return false
+}
+
+// LegalDocument é uma versão publicada de um documento legal do tenant
+// A versão em vigor de cada tipo é a mais recente cuja data de entrada em vigor já passou
+type LegalDocument struct {
+	ID       uuid.UUID         `json:"id"`
+	TenantID uuid.UUID         `json:"tenant_id"`
+	Type     LegalDocumentType `json:"type"`
+	Version  string            `json:"version"`
+	Title    string            `json:"title"`
+	URL      string            `json:"url"`
+	// ContentHash é o SHA-256 (hexadecimal) do conteúdo publicado
+	ContentHash string `json:"content_hash"`
+	// Required indica que o documento tem de ser aceite antes do login
+	Required    bool      `json:"required"`
+	EffectiveAt time.Time `json:"effective_at"`
+	PublishedBy uuid.UUID `json:"published_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsEffective indica se o documento já entrou em vigor
+func (d *LegalDocument) IsEffective(now time.Time) bool {
+	return !d.EffectiveAt.After(now)
+}
+
+// LegalDocumentHash calcula o hash do conteúdo de um documento, no formato de ContentHash
+func LegalDocumentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsValidLegalDocumentHash indica se o valor é um SHA-256 hexadecimal em minúsculas
+func IsValidLegalDocumentHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// LegalAcceptance é o registo imutável da aceitação de uma versão de um documento por um usuário
+// DocumentHash é o hash do conteúdo apresentado ao usuário no momento da aceitação
+type LegalAcceptance struct {
+	ID           uuid.UUID         `json:"id"`
+	TenantID     uuid.UUID         `json:"tenant_id"`
+	UserID       uuid.UUID         `json:"user_id"`
+	DocumentID   uuid.UUID         `json:"document_id"`
+	DocumentType LegalDocumentType `json:"document_type"`
+	Version      string            `json:"version"`
+	DocumentHash string            `json:"document_hash"`
+	AcceptedAt   time.Time         `json:"accepted_at"`
+	IPAddress    string            `json:"ip_address,omitempty"`
+	UserAgent    string            `json:"user_agent,omitempty"`
+}
+
+// LegalAcceptanceFilter filtra os registos de aceitação do tenant
+type LegalAcceptanceFilter struct {
+	UserID     *uuid.UUID
+	DocumentID *uuid.UUID
+	Since      *time.Time
+	Limit      int
+}
+
+// LegalAcceptanceReport resume a aceitação de uma versão de um documento pelos usuários ativos do tenant
+type LegalAcceptanceReport struct {
+	Document *LegalDocument `json:"document"`
+	// ActiveUsers conta os usuários que podem autenticar-se no tenant
+	ActiveUsers int `json:"active_users"`
+	// Accepted conta os usuários ativos que aceitaram esta versão; Pending os restantes
+	Accepted       int       `json:"accepted"`
+	Pending        int       `json:"pending"`
+	AcceptanceRate float64   `json:"acceptance_rate"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// Erros específicos da aceitação de documentos legais
+var (
+	ErrLegalDocumentNotFound        = errors.New("documento legal não encontrado")
+	ErrInvalidLegalDocument         = errors.New("documento legal inválido")
+	ErrLegalDocumentVersionExists   = errors.New("já existe uma versão do documento legal com o mesmo número")
+	ErrLegalDocumentNotCurrent      = errors.New("o documento legal não é a versão em vigor")
+	ErrLegalDocumentHashMismatch    = errors.New("o hash aceite não corresponde ao conteúdo publicado do documento legal")
+	ErrInvalidLegalAcceptanceFilter = errors.New("filtro dos registos de aceitação inválido")
+)
@@ -0,0 +1,515 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Notificações de logins suspeitos: quando um usuário entra a partir de um dispositivo ou
+ * de uma localização que ainda não usou, é avisado pelos canais configurados pelo tenant
+ * (email, SMS ou push por webhook), com mensagens por mercado e idioma. A notificação leva
+ * um link "não fui eu" que termina as sessões do usuário e inicia a redefinição da credencial.
+ */
+
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// LoginNotificationChannel identifica o canal de entrega das notificações de login
+type LoginNotificationChannel string
+
+// Canais de entrega das notificações de login
+const (
+	LoginNotificationChannelEmail LoginNotificationChannel = "email"
+	LoginNotificationChannelSMS   LoginNotificationChannel = "sms"
+	// LoginNotificationChannelPush entrega a notificação ao webhook do serviço de push do tenant
+	LoginNotificationChannelPush LoginNotificationChannel = "push"
+)
+
+// IsValid indica se o canal é conhecido
+func (c LoginNotificationChannel) IsValid() bool {
+	switch c {
+	case LoginNotificationChannelEmail, LoginNotificationChannelSMS, LoginNotificationChannelPush:
+		return true
+	}
+	return false
+}
+
+// LoginNotificationKind identifica a mensagem enviada ao usuário
+type LoginNotificationKind string
+
+// Mensagens enviadas ao usuário
+const (
+	// LoginNotificationKindAlert avisa o usuário do login num dispositivo ou localização novos
+	LoginNotificationKindAlert LoginNotificationKind = "login_alert"
+	// LoginNotificationKindCredentialReset envia o link de redefinição após a resposta "não fui eu"
+	LoginNotificationKindCredentialReset LoginNotificationKind = "credential_reset"
+)
+
+// IsValid indica se a mensagem é conhecida
+func (k LoginNotificationKind) IsValid() bool {
+	return k == LoginNotificationKindAlert || k == LoginNotificationKindCredentialReset
+}
+
+// LoginNotificationReason indica o que tornou o login suspeito
+type LoginNotificationReason string
+
+// Motivos de uma notificação de login
+const (
+	LoginNotificationReasonNewDevice   LoginNotificationReason = "new_device"
+	LoginNotificationReasonNewLocation LoginNotificationReason = "new_location"
+)
+
+// LoginContextKind identifica o tipo de contexto conhecido de um usuário
+type LoginContextKind string
+
+// Tipos de contexto conhecido
+const (
+	LoginContextDevice   LoginContextKind = "device"
+	LoginContextLocation LoginContextKind = "location"
+)
+
+// Estados da entrega de uma notificação num canal
+const (
+	LoginDeliverySent    = "sent"
+	LoginDeliveryFailed  = "failed"
+	LoginDeliverySkipped = "skipped" // Canal sem remetente configurado ou usuário sem contacto nesse canal
+)
+
+// Limites das notificações de login
+const (
+	MaxLoginNotificationSubjectSize = 200
+	MaxLoginNotificationBodySize    = 4000
+	// As mensagens SMS mais longas são entregues em várias partes pelo operador
+	MaxLoginNotificationSMSSize = 480
+	// Modelos personalizados por tenant
+	MaxLoginNotificationTemplates = 200
+)
+
+// DefaultLoginNotificationLocale é o idioma usado quando nem o usuário nem o mercado o determinam
+const DefaultLoginNotificationLocale = "en"
+
+// Erros das notificações de login
+var (
+	ErrInvalidLoginNotificationSettings  = errors.New("configuração das notificações de login inválida")
+	ErrInvalidLoginNotificationTemplate  = errors.New("modelo de notificação de login inválido")
+	ErrLoginNotificationTemplateNotFound = errors.New("modelo de notificação de login não encontrado")
+	ErrLoginNotificationNotFound         = errors.New("notificação de login não encontrada")
+	// ErrInvalidLoginResponseToken é retornado para links inválidos, expirados ou já usados, sem distinção
+	ErrInvalidLoginResponseToken = errors.New("link de resposta à notificação de login inválido, expirado ou já utilizado")
+)
+
+// loginNotificationMarketLocales define o idioma padrão de cada mercado
+var loginNotificationMarketLocales = map[string]string{
+	"angola":     "pt-PT",
+	"mozambique": "pt-PT",
+	"brazil":     "pt-BR",
+	"eu":         "en",
+	"usa":        "en",
+}
+
+// LoginNotificationSettings representa a configuração das notificações de login de um tenant
+type LoginNotificationSettings struct {
+	TenantID uuid.UUID                  `json:"tenant_id"`
+	Enabled  bool                       `json:"enabled"`
+	Channels []LoginNotificationChannel `json:"channels"`
+	// Market escolhe os modelos do mercado e o idioma padrão
+	Market string `json:"market,omitempty"`
+	// DefaultLocale é usado para os usuários sem idioma; vazio usa o idioma do mercado
+	DefaultLocale string `json:"default_locale,omitempty"`
+	// NotifyFirstLogin avisa também no primeiro login registado do usuário, em que todos os contextos são novos
+	NotifyFirstLogin bool      `json:"notify_first_login"`
+	UpdatedBy        uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// DefaultLoginNotificationSettings retorna a configuração de um tenant que ainda não configurou as notificações
+func DefaultLoginNotificationSettings(tenantID uuid.UUID) *LoginNotificationSettings {
+	return &LoginNotificationSettings{
+		TenantID: tenantID,
+		Enabled:  false,
+		Channels: []LoginNotificationChannel{LoginNotificationChannelEmail},
+	}
+}
+
+// Validate verifica os canais e o idioma padrão
+func (s *LoginNotificationSettings) Validate() error {
+	if s.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if len(s.Channels) == 0 {
+		return fmt.Errorf("%w: pelo menos um canal é obrigatório", ErrInvalidLoginNotificationSettings)
+	}
+	seen := make(map[LoginNotificationChannel]bool, len(s.Channels))
+	for _, channel := range s.Channels {
+		if !channel.IsValid() {
+			return fmt.Errorf("%w: canal desconhecido %q", ErrInvalidLoginNotificationSettings, channel)
+		}
+		if seen[channel] {
+			return fmt.Errorf("%w: canal %q repetido", ErrInvalidLoginNotificationSettings, channel)
+		}
+		seen[channel] = true
+	}
+	if s.DefaultLocale != "" && NormalizeLoginNotificationLocale(s.DefaultLocale) == "" {
+		return fmt.Errorf("%w: idioma padrão inválido %q", ErrInvalidLoginNotificationSettings, s.DefaultLocale)
+	}
+	return nil
+}
+
+// Locale retorna o idioma de um usuário: o seu idioma, o idioma padrão do tenant ou o do mercado
+func (s *LoginNotificationSettings) Locale(userLocale string) string {
+	if locale := NormalizeLoginNotificationLocale(userLocale); locale != "" {
+		return locale
+	}
+	if locale := NormalizeLoginNotificationLocale(s.DefaultLocale); locale != "" {
+		return locale
+	}
+	if locale, ok := loginNotificationMarketLocales[strings.ToLower(strings.TrimSpace(s.Market))]; ok {
+		return locale
+	}
+	return DefaultLoginNotificationLocale
+}
+
+// NormalizeLoginNotificationLocale normaliza um idioma BCP 47 simples (ex.: "pt_br" para "pt-BR")
+// Retorna vazio para valores inválidos
+func NormalizeLoginNotificationLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if len(parts) > 2 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isASCIILetters(parts[0]) {
+		return ""
+	}
+	normalized := strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		if len(parts[1]) != 2 || !isASCIILetters(parts[1]) {
+			return ""
+		}
+		normalized += "-" + strings.ToUpper(parts[1])
+	}
+	return normalized
+}
+
+// isASCIILetters indica se o valor só tem letras ASCII
+func isASCIILetters(value string) bool {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// LoginNotificationTemplate representa uma mensagem personalizada pelo tenant para um canal,
+// mercado e idioma. O assunto e o corpo são modelos text/template com os campos de
+// LoginNotificationTemplateData; Market vazio aplica-se a todos os mercados
+type LoginNotificationTemplate struct {
+	ID        uuid.UUID                `json:"id"`
+	TenantID  uuid.UUID                `json:"tenant_id"`
+	Kind      LoginNotificationKind    `json:"kind"`
+	Channel   LoginNotificationChannel `json:"channel"`
+	Market    string                   `json:"market,omitempty"`
+	Locale    string                   `json:"locale"`
+	Subject   string                   `json:"subject,omitempty"`
+	Body      string                   `json:"body"`
+	UpdatedBy uuid.UUID                `json:"updated_by,omitempty"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// Validate verifica os campos e compila o assunto e o corpo contra dados de exemplo, para que
+// um modelo com campos inexistentes seja recusado na gravação e não no envio
+func (t *LoginNotificationTemplate) Validate() error {
+	if t.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !t.Kind.IsValid() {
+		return fmt.Errorf("%w: mensagem desconhecida %q", ErrInvalidLoginNotificationTemplate, t.Kind)
+	}
+	if !t.Channel.IsValid() {
+		return fmt.Errorf("%w: canal desconhecido %q", ErrInvalidLoginNotificationTemplate, t.Channel)
+	}
+	t.Market = strings.ToLower(strings.TrimSpace(t.Market))
+	if t.Locale = NormalizeLoginNotificationLocale(t.Locale); t.Locale == "" {
+		return fmt.Errorf("%w: idioma inválido", ErrInvalidLoginNotificationTemplate)
+	}
+	if t.Channel != LoginNotificationChannelSMS && strings.TrimSpace(t.Subject) == "" {
+		return fmt.Errorf("%w: o assunto é obrigatório no canal %q", ErrInvalidLoginNotificationTemplate, t.Channel)
+	}
+	if utf8.RuneCountInString(t.Subject) > MaxLoginNotificationSubjectSize || strings.ContainsAny(t.Subject, "\r\n") {
+		return fmt.Errorf("%w: assunto demasiado longo ou com quebras de linha", ErrInvalidLoginNotificationTemplate)
+	}
+	maxBody := MaxLoginNotificationBodySize
+	if t.Channel == LoginNotificationChannelSMS {
+		maxBody = MaxLoginNotificationSMSSize
+	}
+	if strings.TrimSpace(t.Body) == "" || utf8.RuneCountInString(t.Body) > maxBody {
+		return fmt.Errorf("%w: o corpo é obrigatório e tem no máximo %d caracteres", ErrInvalidLoginNotificationTemplate, maxBody)
+	}
+	if _, _, err := t.Render(sampleLoginNotificationData()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLoginNotificationTemplate, err)
+	}
+	return nil
+}
+
+// Render produz o assunto e o corpo da mensagem
+func (t *LoginNotificationTemplate) Render(data *LoginNotificationTemplateData) (subject, body string, err error) {
+	if subject, err = renderLoginNotificationText("subject", t.Subject, data); err != nil {
+		return "", "", err
+	}
+	if body, err = renderLoginNotificationText("body", t.Body, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject), body, nil
+}
+
+// renderLoginNotificationText compila e executa um modelo, recusando campos inexistentes
+func renderLoginNotificationText(name, text string, data *LoginNotificationTemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// LoginNotificationTemplateData contém os campos disponíveis nos modelos das notificações
+type LoginNotificationTemplateData struct {
+	Name      string // Nome de apresentação do usuário
+	Time      string // Instante do login no fuso horário do usuário
+	Device    string // Dispositivo ou navegador, quando conhecido
+	Location  string // País ou rede de origem, quando conhecidos
+	IPAddress string
+	// ResponseURL é o link "não fui eu" das notificações de login
+	ResponseURL string
+	// ResetURL é o link de redefinição da credencial, apenas nas mensagens credential_reset
+	ResetURL  string
+	ExpiresAt string
+}
+
+// sampleLoginNotificationData retorna dados de exemplo usados na validação dos modelos
+func sampleLoginNotificationData() *LoginNotificationTemplateData {
+	return &LoginNotificationTemplateData{
+		Name:        "Ana Silva",
+		Time:        "16/10/2026 09:30 WAT",
+		Device:      "Firefox 131 (Windows)",
+		Location:    "AO",
+		IPAddress:   "192.0.2.10",
+		ResponseURL: "https://example.com/login-notifications/respond?token=x",
+		ResetURL:    "https://example.com/credential-reset?token=x",
+		ExpiresAt:   "17/10/2026 09:30 WAT",
+	}
+}
+
+// builtinLoginNotificationTemplate é a mensagem padrão de um idioma
+type builtinLoginNotificationTemplate struct {
+	Subject string
+	Body    string
+	SMS     string
+}
+
+// builtinLoginNotificationTemplates são as mensagens usadas quando o tenant não personalizou o canal,
+// o mercado e o idioma, pelos idiomas dos catálogos da API
+var builtinLoginNotificationTemplates = map[LoginNotificationKind]map[string]builtinLoginNotificationTemplate{
+	LoginNotificationKindAlert: {
+		"pt-PT": {
+			Subject: "Novo início de sessão na sua conta",
+			Body: "Olá {{.Name}},\n\nFoi iniciada uma sessão na sua conta em {{.Time}} a partir de um dispositivo ou localização que não reconhecemos.\n\n" +
+				"Dispositivo: {{.Device}}\nLocalização: {{.Location}}\nEndereço IP: {{.IPAddress}}\n\n" +
+				"Se foi o utilizador, não precisa de fazer nada. Se não foi, termine as sessões e redefina a sua credencial em:\n{{.ResponseURL}}\n",
+			SMS: "Nova sessão na sua conta em {{.Time}} ({{.Location}}). Não foi o utilizador? {{.ResponseURL}}",
+		},
+		"pt-BR": {
+			Subject: "Novo acesso à sua conta",
+			Body: "Olá {{.Name}},\n\nSua conta foi acessada em {{.Time}} a partir de um dispositivo ou local que não reconhecemos.\n\n" +
+				"Dispositivo: {{.Device}}\nLocal: {{.Location}}\nEndereço IP: {{.IPAddress}}\n\n" +
+				"Se foi você, não é preciso fazer nada. Se não foi, encerre as sessões e redefina sua credencial em:\n{{.ResponseURL}}\n",
+			SMS: "Novo acesso à sua conta em {{.Time}} ({{.Location}}). Não foi você? {{.ResponseURL}}",
+		},
+		"en": {
+			Subject: "New sign-in to your account",
+			Body: "Hello {{.Name}},\n\nYour account was signed in to on {{.Time}} from a device or location we don't recognise.\n\n" +
+				"Device: {{.Device}}\nLocation: {{.Location}}\nIP address: {{.IPAddress}}\n\n" +
+				"If this was you, there is nothing to do. If it wasn't, end your sessions and reset your credential at:\n{{.ResponseURL}}\n",
+			SMS: "New sign-in to your account on {{.Time}} ({{.Location}}). Wasn't you? {{.ResponseURL}}",
+		},
+		"es": {
+			Subject: "Nuevo inicio de sesión en su cuenta",
+			Body: "Hola {{.Name}},\n\nSe inició sesión en su cuenta el {{.Time}} desde un dispositivo o ubicación que no reconocemos.\n\n" +
+				"Dispositivo: {{.Device}}\nUbicación: {{.Location}}\nDirección IP: {{.IPAddress}}\n\n" +
+				"Si fue usted, no tiene que hacer nada. Si no, cierre las sesiones y restablezca su credencial en:\n{{.ResponseURL}}\n",
+			SMS: "Nuevo inicio de sesión en su cuenta el {{.Time}} ({{.Location}}). ¿No fue usted? {{.ResponseURL}}",
+		},
+		"fr": {
+			Subject: "Nouvelle connexion à votre compte",
+			Body: "Bonjour {{.Name}},\n\nUne connexion à votre compte a eu lieu le {{.Time}} depuis un appareil ou un lieu que nous ne reconnaissons pas.\n\n" +
+				"Appareil : {{.Device}}\nLieu : {{.Location}}\nAdresse IP : {{.IPAddress}}\n\n" +
+				"Si c'était vous, vous n'avez rien à faire. Sinon, fermez vos sessions et réinitialisez votre identifiant ici :\n{{.ResponseURL}}\n",
+			SMS: "Nouvelle connexion à votre compte le {{.Time}} ({{.Location}}). Ce n'était pas vous ? {{.ResponseURL}}",
+		},
+	},
+	LoginNotificationKindCredentialReset: {
+		"pt-PT": {
+			Subject: "Redefinição da credencial da sua conta",
+			Body:    "Olá {{.Name}},\n\nTerminámos as sessões abertas na sua conta. Defina uma nova credencial até {{.ExpiresAt}} em:\n{{.ResetURL}}\n",
+			SMS:     "Terminámos as sessões da sua conta. Redefina a credencial até {{.ExpiresAt}}: {{.ResetURL}}",
+		},
+		"pt-BR": {
+			Subject: "Redefinição da credencial da sua conta",
+			Body:    "Olá {{.Name}},\n\nEncerramos as sessões abertas na sua conta. Defina uma nova credencial até {{.ExpiresAt}} em:\n{{.ResetURL}}\n",
+			SMS:     "Encerramos as sessões da sua conta. Redefina a credencial até {{.ExpiresAt}}: {{.ResetURL}}",
+		},
+		"en": {
+			Subject: "Reset your account credential",
+			Body:    "Hello {{.Name}},\n\nWe have ended the open sessions on your account. Set a new credential before {{.ExpiresAt}} at:\n{{.ResetURL}}\n",
+			SMS:     "We ended the sessions on your account. Reset your credential before {{.ExpiresAt}}: {{.ResetURL}}",
+		},
+		"es": {
+			Subject: "Restablecimiento de la credencial de su cuenta",
+			Body:    "Hola {{.Name}},\n\nHemos cerrado las sesiones abiertas en su cuenta. Defina una nueva credencial antes del {{.ExpiresAt}} en:\n{{.ResetURL}}\n",
+			SMS:     "Cerramos las sesiones de su cuenta. Restablezca la credencial antes del {{.ExpiresAt}}: {{.ResetURL}}",
+		},
+		"fr": {
+			Subject: "Réinitialisation de l'identifiant de votre compte",
+			Body:    "Bonjour {{.Name}},\n\nNous avons fermé les sessions ouvertes sur votre compte. Définissez un nouvel identifiant avant le {{.ExpiresAt}} ici :\n{{.ResetURL}}\n",
+			SMS:     "Nous avons fermé les sessions de votre compte. Réinitialisez l'identifiant avant le {{.ExpiresAt}} : {{.ResetURL}}",
+		},
+	},
+}
+
+// BuiltinLoginNotificationTemplate retorna a mensagem padrão da mensagem, canal e idioma
+// Sem mensagem no idioma, usa a da mesma língua (ex.: "pt-BR" para "pt") e, por fim, o inglês
+func BuiltinLoginNotificationTemplate(kind LoginNotificationKind, channel LoginNotificationChannel, locale string) *LoginNotificationTemplate {
+	builtins := builtinLoginNotificationTemplates[kind]
+	builtin, ok := builtins[locale]
+	if !ok {
+		language := strings.SplitN(locale, "-", 2)[0]
+		for _, candidate := range []string{language, language + "-PT"} {
+			if builtin, ok = builtins[candidate]; ok {
+				locale = candidate
+				break
+			}
+		}
+	}
+	if !ok {
+		locale = DefaultLoginNotificationLocale
+		builtin = builtins[locale]
+	}
+
+	tmpl := &LoginNotificationTemplate{Kind: kind, Channel: channel, Locale: locale, Subject: builtin.Subject, Body: builtin.Body}
+	if channel == LoginNotificationChannelSMS {
+		tmpl.Subject, tmpl.Body = "", builtin.SMS
+	}
+	return tmpl
+}
+
+// LoginEvent representa um login concluído, observado para as notificações de login
+type LoginEvent struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	// Method identifica o método de autenticação (ex.: magic_link, email_otp)
+	Method    string `json:"method"`
+	DeviceID  string `json:"device_id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// Country é o país de origem (ISO 3166-1 alfa-2) indicado pelo proxy de entrada, quando existe
+	Country    string    `json:"country,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// DeviceKey retorna o identificador do dispositivo: o indicado pelo cliente ou, na sua falta, o agente
+func (e *LoginEvent) DeviceKey() string {
+	if e.DeviceID != "" {
+		return "id:" + e.DeviceID
+	}
+	if ua := strings.TrimSpace(e.UserAgent); ua != "" {
+		return "ua:" + ua
+	}
+	return ""
+}
+
+// LocationKey retorna a localização do login: o país ou, na sua falta, a rede do endereço IP
+// (/24 em IPv4 e /48 em IPv6), para que a mudança de endereço dentro da mesma rede não conte
+func (e *LoginEvent) LocationKey() string {
+	if country := strings.ToUpper(strings.TrimSpace(e.Country)); len(country) == 2 && isASCIILetters(country) {
+		return "country:" + country
+	}
+	ip := net.ParseIP(strings.TrimSpace(e.IPAddress))
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return "net:" + v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return "net:" + ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+// LoginContextFingerprint representa um contexto (dispositivo ou localização) de um login
+// Apenas o HMAC do contexto é gravado
+type LoginContextFingerprint struct {
+	Kind        LoginContextKind `json:"kind"`
+	Fingerprint string           `json:"-"`
+}
+
+// LoginNotificationDelivery representa a entrega de uma notificação num canal
+type LoginNotificationDelivery struct {
+	Channel LoginNotificationChannel `json:"channel"`
+	Status  string                   `json:"status"`
+	Locale  string                   `json:"locale,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// LoginNotification representa uma notificação de login suspeito enviada a um usuário
+// Apenas o HMAC do segredo do link "não fui eu" é gravado; o link é de uso único
+type LoginNotification struct {
+	ID           uuid.UUID                   `json:"id"`
+	TenantID     uuid.UUID                   `json:"tenant_id"`
+	UserID       uuid.UUID                   `json:"user_id"`
+	Reasons      []LoginNotificationReason   `json:"reasons"`
+	Method       string                      `json:"method,omitempty"`
+	IPAddress    string                      `json:"ip_address,omitempty"`
+	UserAgent    string                      `json:"user_agent,omitempty"`
+	Country      string                      `json:"country,omitempty"`
+	Contexts     []LoginContextFingerprint   `json:"-"`
+	Deliveries   []LoginNotificationDelivery `json:"deliveries"`
+	ResponseHash string                      `json:"-"`
+	LoginAt      time.Time                   `json:"login_at"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	ExpiresAt    time.Time                   `json:"expires_at"`
+	// RespondedAt indica quando o usuário respondeu "não fui eu"
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	// SessionsEnded é o número de sessões terminadas pela resposta
+	SessionsEnded int `json:"sessions_ended,omitempty"`
+}
+
+// LoginNotificationRecipient contém os dados de contacto e de apresentação de um usuário
+type LoginNotificationRecipient struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	TenantID    uuid.UUID  `json:"tenant_id"`
+	Email       string     `json:"email"`
+	PhoneNumber string     `json:"phone_number,omitempty"`
+	DisplayName string     `json:"display_name"`
+	Locale      string     `json:"locale,omitempty"`
+	Timezone    string     `json:"timezone,omitempty"`
+	Status      UserStatus `json:"status"`
+}
+
+// CredentialResetToken representa o pedido de redefinição da credencial criado pela resposta "não fui eu"
+// Apenas o SHA-256 do token é gravado, para que a página de redefinição o possa procurar sem a chave do serviço
+type CredentialResetToken struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
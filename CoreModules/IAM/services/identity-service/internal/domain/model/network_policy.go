@@ -0,0 +1,247 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Políticas de rede por tenant: listas de endereços (CIDR) permitidos e bloqueados
+ * para a API de administração e para funções específicas, com acesso de emergência
+ * (break-glass) temporário e auditado para os usuários bloqueados.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NetworkPolicyScope define os pedidos a que a política se aplica
+type NetworkPolicyScope string
+
+// Âmbitos das políticas de rede
+const (
+	// NetworkPolicyScopeAdminAPI aplica-se a todos os pedidos à API de administração
+	NetworkPolicyScopeAdminAPI NetworkPolicyScope = "admin_api"
+	// NetworkPolicyScopeRole aplica-se aos pedidos dos usuários com a função indicada
+	NetworkPolicyScopeRole NetworkPolicyScope = "role"
+)
+
+// IsValid indica se o âmbito é conhecido
+func (s NetworkPolicyScope) IsValid() bool {
+	return s == NetworkPolicyScopeAdminAPI || s == NetworkPolicyScopeRole
+}
+
+// NetworkPolicyAction define o efeito da política sobre os endereços listados
+type NetworkPolicyAction string
+
+// Ações das políticas de rede
+const (
+	NetworkPolicyActionAllow NetworkPolicyAction = "allow"
+	NetworkPolicyActionDeny  NetworkPolicyAction = "deny"
+)
+
+// IsValid indica se a ação é conhecida
+func (a NetworkPolicyAction) IsValid() bool {
+	return a == NetworkPolicyActionAllow || a == NetworkPolicyActionDeny
+}
+
+// Motivos de bloqueio de um pedido
+const (
+	NetworkBlockReasonDenied       = "denied_by_policy"
+	NetworkBlockReasonNotAllowed   = "not_in_allow_list"
+	NetworkBlockReasonInvalidIP    = "invalid_client_ip"
+	NetworkBlockReasonAuditFailure = "break_glass_audit_failed"
+)
+
+// Limites das políticas de rede
+const (
+	MaxNetworkPolicyCIDRs          = 256
+	MinBreakGlassJustificationSize = 20
+)
+
+// Erros das políticas de rede
+var (
+	ErrNetworkPolicyNotFound  = errors.New("política de rede não encontrada")
+	ErrInvalidNetworkPolicy   = errors.New("política de rede inválida")
+	ErrNetworkPolicyConflict  = errors.New("já existe uma política de rede com este nome no tenant")
+	ErrBreakGlassNotFound     = errors.New("acesso de emergência não encontrado ou já revogado")
+	ErrInvalidBreakGlass      = errors.New("pedido de acesso de emergência inválido")
+	ErrBreakGlassAuditFailure = errors.New("não foi possível auditar o uso do acesso de emergência")
+)
+
+// NetworkPolicy representa uma lista de endereços permitidos ou bloqueados de um tenant
+type NetworkPolicy struct {
+	ID       uuid.UUID           `json:"id"`
+	TenantID uuid.UUID           `json:"tenant_id"`
+	Name     string              `json:"name"`
+	Scope    NetworkPolicyScope  `json:"scope"`
+	Action   NetworkPolicyAction `json:"action"`
+	// RoleCode identifica a função, nas políticas de âmbito role
+	RoleCode  string    `json:"role_code,omitempty"`
+	CIDRs     []string  `json:"cidrs"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy uuid.UUID `json:"created_by,omitempty"`
+	UpdatedBy uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Normalize uniformiza o nome, a função e os blocos de endereços
+// Endereços sem prefixo são convertidos em blocos de um único endereço (/32 ou /128)
+func (p *NetworkPolicy) Normalize() error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.RoleCode = strings.TrimSpace(p.RoleCode)
+
+	normalized := make([]string, 0, len(p.CIDRs))
+	seen := make(map[string]bool, len(p.CIDRs))
+	for _, value := range p.CIDRs {
+		network, err := ParseNetworkCIDR(value)
+		if err != nil {
+			return err
+		}
+		cidr := network.String()
+		if !seen[cidr] {
+			seen[cidr] = true
+			normalized = append(normalized, cidr)
+		}
+	}
+	p.CIDRs = normalized
+	return nil
+}
+
+// Validate verifica a política
+func (p *NetworkPolicy) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if p.Name == "" {
+		return fmt.Errorf("%w: o nome é obrigatório", ErrInvalidNetworkPolicy)
+	}
+	if !p.Scope.IsValid() {
+		return fmt.Errorf("%w: âmbito desconhecido %q", ErrInvalidNetworkPolicy, p.Scope)
+	}
+	if !p.Action.IsValid() {
+		return fmt.Errorf("%w: ação desconhecida %q", ErrInvalidNetworkPolicy, p.Action)
+	}
+	if p.Scope == NetworkPolicyScopeRole && p.RoleCode == "" {
+		return fmt.Errorf("%w: as políticas de âmbito role exigem a função", ErrInvalidNetworkPolicy)
+	}
+	if p.Scope == NetworkPolicyScopeAdminAPI && p.RoleCode != "" {
+		return fmt.Errorf("%w: as políticas de âmbito admin_api não indicam função", ErrInvalidNetworkPolicy)
+	}
+	if len(p.CIDRs) == 0 {
+		return fmt.Errorf("%w: pelo menos um bloco de endereços é obrigatório", ErrInvalidNetworkPolicy)
+	}
+	if len(p.CIDRs) > MaxNetworkPolicyCIDRs {
+		return fmt.Errorf("%w: no máximo %d blocos de endereços", ErrInvalidNetworkPolicy, MaxNetworkPolicyCIDRs)
+	}
+	for _, cidr := range p.CIDRs {
+		if _, err := ParseNetworkCIDR(cidr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppliesTo indica se a política se aplica ao pedido
+// As funções são comparadas sem distinção de maiúsculas
+func (p *NetworkPolicy) AppliesTo(req *NetworkAccessRequest) bool {
+	if !p.Enabled {
+		return false
+	}
+	switch p.Scope {
+	case NetworkPolicyScopeAdminAPI:
+		return req.AdminAPI
+	case NetworkPolicyScopeRole:
+		for _, role := range req.Roles {
+			if strings.EqualFold(role, p.RoleCode) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseNetworkCIDR interpreta um bloco CIDR ou um endereço isolado
+func ParseNetworkCIDR(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("%w: endereço inválido %q", ErrInvalidNetworkPolicy, value)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bloco CIDR inválido %q", ErrInvalidNetworkPolicy, value)
+	}
+	return network, nil
+}
+
+// NetworkAccessRequest descreve o pedido avaliado pelas políticas de rede
+type NetworkAccessRequest struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	// AdminAPI indica que o pedido se destina à API de administração
+	AdminAPI bool   `json:"admin_api"`
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// NetworkDecision representa o resultado da avaliação das políticas de rede
+type NetworkDecision struct {
+	Allowed bool `json:"allowed"`
+	// Reason e Scope explicam o bloqueio; ficam preenchidos também quando o acesso de
+	// emergência permitiu um pedido que seria bloqueado
+	Reason   string             `json:"reason,omitempty"`
+	Scope    NetworkPolicyScope `json:"scope,omitempty"`
+	RoleCode string             `json:"role_code,omitempty"`
+	PolicyID *uuid.UUID         `json:"policy_id,omitempty"`
+	// BreakGlassID identifica o acesso de emergência que permitiu o pedido
+	BreakGlassID *uuid.UUID `json:"break_glass_id,omitempty"`
+}
+
+// BreakGlassOverride representa um acesso de emergência temporário de um usuário,
+// que ignora as políticas de rede do tenant até expirar ou ser revogado
+type BreakGlassOverride struct {
+	ID            uuid.UUID  `json:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Justification string     `json:"justification"`
+	TicketRef     string     `json:"ticket_ref,omitempty"`
+	GrantedBy     uuid.UUID  `json:"granted_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy     *uuid.UUID `json:"revoked_by,omitempty"`
+}
+
+// IsActive indica se o acesso de emergência está em vigor no instante indicado
+func (o *BreakGlassOverride) IsActive(now time.Time) bool {
+	return o.RevokedAt == nil && now.Before(o.ExpiresAt)
+}
+
+// BreakGlassUse regista um pedido que só foi permitido pelo acesso de emergência
+type BreakGlassUse struct {
+	ID         uuid.UUID `json:"id"`
+	OverrideID uuid.UUID `json:"override_id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	IPAddress  string    `json:"ip_address"`
+	Method     string    `json:"method,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	// BlockedReason e PolicyID indicam o bloqueio que foi ignorado
+	BlockedReason string     `json:"blocked_reason"`
+	PolicyID      *uuid.UUID `json:"policy_id,omitempty"`
+	UsedAt        time.Time  `json:"used_at"`
+}
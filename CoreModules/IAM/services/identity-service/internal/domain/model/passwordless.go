@@ -0,0 +1,198 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Autenticação sem senha por link mágico ou por código de uso único enviado por email.
+ * Cada tenant escolhe os métodos aceites e a vinculação ao dispositivo; o mercado do
+ * tenant determina se o modo sem senha é permitido a tenants do setor financeiro.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordlessMethod identifica o método de autenticação sem senha
+type PasswordlessMethod string
+
+// Métodos de autenticação sem senha
+const (
+	PasswordlessMethodMagicLink PasswordlessMethod = "magic_link"
+	PasswordlessMethodEmailOTP  PasswordlessMethod = "email_otp"
+)
+
+// IsValid indica se o método é conhecido
+func (m PasswordlessMethod) IsValid() bool {
+	return m == PasswordlessMethodMagicLink || m == PasswordlessMethodEmailOTP
+}
+
+// PasswordlessDeviceBinding define se o desafio tem de ser concluído no dispositivo que o pediu
+type PasswordlessDeviceBinding string
+
+// Modos de vinculação ao dispositivo
+const (
+	// PasswordlessDeviceBindingNone ignora o dispositivo
+	PasswordlessDeviceBindingNone PasswordlessDeviceBinding = "none"
+	// PasswordlessDeviceBindingOptional exige o mesmo dispositivo quando o pedido o identificou
+	PasswordlessDeviceBindingOptional PasswordlessDeviceBinding = "optional"
+	// PasswordlessDeviceBindingRequired exige que o pedido identifique o dispositivo e que o desafio seja concluído nele
+	PasswordlessDeviceBindingRequired PasswordlessDeviceBinding = "required"
+)
+
+// passwordlessDeviceBindingStrictness ordena os modos de vinculação pelo nível de proteção
+var passwordlessDeviceBindingStrictness = map[PasswordlessDeviceBinding]int{
+	PasswordlessDeviceBindingNone:     1,
+	PasswordlessDeviceBindingOptional: 2,
+	PasswordlessDeviceBindingRequired: 3,
+}
+
+// IsValid indica se o modo de vinculação é conhecido
+func (b PasswordlessDeviceBinding) IsValid() bool {
+	_, ok := passwordlessDeviceBindingStrictness[b]
+	return ok
+}
+
+// Erros da autenticação sem senha
+var (
+	ErrPasswordlessDisabled          = errors.New("autenticação sem senha desativada no tenant")
+	ErrPasswordlessNotAllowed        = errors.New("o mercado do tenant não permite autenticação sem senha a tenants financeiros")
+	ErrPasswordlessMethodNotAllowed  = errors.New("método de autenticação sem senha não aceite pelo tenant")
+	ErrInvalidPasswordlessSettings   = errors.New("configuração de autenticação sem senha inválida")
+	ErrPasswordlessRateLimited       = errors.New("demasiados pedidos de autenticação sem senha; tente mais tarde")
+	ErrInvalidPasswordlessToken      = errors.New("link ou código de autenticação inválido, expirado ou já utilizado")
+	ErrPasswordlessDeviceRequired    = errors.New("a autenticação sem senha exige a identificação do dispositivo")
+	ErrPasswordlessChallengeNotFound = errors.New("desafio de autenticação sem senha inexistente, expirado ou esgotado")
+)
+
+// PasswordlessMarketPolicy define as condições em que o mercado permite autenticação sem senha
+// a tenants do setor financeiro; os restantes tenants não são restringidos pelo mercado
+type PasswordlessMarketPolicy struct {
+	AllowFinancial bool `json:"allow_financial"`
+	// FinancialDeviceBinding é a vinculação mínima exigida aos tenants financeiros
+	FinancialDeviceBinding PasswordlessDeviceBinding `json:"financial_device_binding,omitempty"`
+}
+
+// passwordlessMarketPolicies define a política de cada mercado
+// Na UE (PSD2, RTS art. 4) a posse do email não constitui autenticação forte, e o BNA e o
+// Banco de Moçambique exigem dois fatores nos serviços financeiros; Brasil e EUA aceitam o
+// modo sem senha quando o desafio fica vinculado ao dispositivo que o pediu
+var passwordlessMarketPolicies = map[string]PasswordlessMarketPolicy{
+	"eu":         {AllowFinancial: false},
+	"angola":     {AllowFinancial: false},
+	"mozambique": {AllowFinancial: false},
+	"brazil":     {AllowFinancial: true, FinancialDeviceBinding: PasswordlessDeviceBindingRequired},
+	"usa":        {AllowFinancial: true, FinancialDeviceBinding: PasswordlessDeviceBindingRequired},
+}
+
+// PasswordlessMarketPolicyFor retorna a política do mercado
+// Os mercados sem política própria não permitem o modo sem senha a tenants financeiros
+func PasswordlessMarketPolicyFor(market string) PasswordlessMarketPolicy {
+	if policy, ok := passwordlessMarketPolicies[strings.ToLower(strings.TrimSpace(market))]; ok {
+		return policy
+	}
+	return PasswordlessMarketPolicy{AllowFinancial: false}
+}
+
+// PasswordlessSettings representa a configuração da autenticação sem senha de um tenant
+type PasswordlessSettings struct {
+	TenantID      uuid.UUID                 `json:"tenant_id"`
+	Enabled       bool                      `json:"enabled"`
+	Methods       []PasswordlessMethod      `json:"methods"`
+	DeviceBinding PasswordlessDeviceBinding `json:"device_binding"`
+	Market        string                    `json:"market,omitempty"`
+	// FinancialTenant indica que o tenant presta serviços financeiros e está sujeito à política do mercado
+	FinancialTenant bool      `json:"financial_tenant"`
+	UpdatedBy       uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// DefaultPasswordlessSettings retorna a configuração de um tenant que ainda não configurou o modo sem senha
+func DefaultPasswordlessSettings(tenantID uuid.UUID) *PasswordlessSettings {
+	return &PasswordlessSettings{
+		TenantID:      tenantID,
+		Enabled:       false,
+		Methods:       []PasswordlessMethod{PasswordlessMethodMagicLink, PasswordlessMethodEmailOTP},
+		DeviceBinding: PasswordlessDeviceBindingOptional,
+	}
+}
+
+// Validate verifica a configuração e a sua conformidade com a política do mercado
+func (s *PasswordlessSettings) Validate() error {
+	if s.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if len(s.Methods) == 0 {
+		return fmt.Errorf("%w: pelo menos um método é obrigatório", ErrInvalidPasswordlessSettings)
+	}
+	seen := make(map[PasswordlessMethod]bool, len(s.Methods))
+	for _, method := range s.Methods {
+		if !method.IsValid() {
+			return fmt.Errorf("%w: método desconhecido %q", ErrInvalidPasswordlessSettings, method)
+		}
+		if seen[method] {
+			return fmt.Errorf("%w: método %q repetido", ErrInvalidPasswordlessSettings, method)
+		}
+		seen[method] = true
+	}
+	if !s.DeviceBinding.IsValid() {
+		return fmt.Errorf("%w: vinculação ao dispositivo desconhecida %q", ErrInvalidPasswordlessSettings, s.DeviceBinding)
+	}
+	if s.Enabled {
+		return s.CheckMarketPolicy()
+	}
+	return nil
+}
+
+// CheckMarketPolicy verifica que o mercado permite a autenticação sem senha configurada
+// É avaliada também em cada login, para que uma política mais restritiva se aplique de imediato
+func (s *PasswordlessSettings) CheckMarketPolicy() error {
+	if !s.FinancialTenant {
+		return nil
+	}
+	policy := PasswordlessMarketPolicyFor(s.Market)
+	if !policy.AllowFinancial {
+		return ErrPasswordlessNotAllowed
+	}
+	if passwordlessDeviceBindingStrictness[s.DeviceBinding] < passwordlessDeviceBindingStrictness[policy.FinancialDeviceBinding] {
+		return fmt.Errorf("%w: o mercado exige vinculação ao dispositivo %q para tenants financeiros",
+			ErrInvalidPasswordlessSettings, policy.FinancialDeviceBinding)
+	}
+	return nil
+}
+
+// Allows indica se o método é aceite pelo tenant
+func (s *PasswordlessSettings) Allows(method PasswordlessMethod) bool {
+	for _, allowed := range s.Methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordlessChallenge representa um link mágico ou um código enviado por email
+// Apenas o HMAC do segredo e do dispositivo é gravado; o desafio é de uso único
+type PasswordlessChallenge struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	// UserID é nulo nos desafios pedidos para emails sem conta, que nunca são enviados
+	// mas contam para o limite de pedidos e tornam a resposta indistinguível
+	UserID      uuid.UUID          `json:"user_id"`
+	Email       string             `json:"email"`
+	Method      PasswordlessMethod `json:"method"`
+	SecretHash  string             `json:"-"`
+	DeviceHash  string             `json:"-"`
+	IPAddress   string             `json:"ip_address,omitempty"`
+	UserAgent   string             `json:"user_agent,omitempty"`
+	Attempts    int                `json:"attempts"`
+	MaxAttempts int                `json:"max_attempts"`
+	CreatedAt   time.Time          `json:"created_at"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	ConsumedAt  *time.Time         `json:"consumed_at,omitempty"`
+}
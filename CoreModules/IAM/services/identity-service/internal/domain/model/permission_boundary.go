@@ -0,0 +1,234 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Limites de permissões (permission boundaries): o conjunto máximo de permissões que uma
+ * função pode deter, diretamente ou por herança, ou que um administrador delegado pode
+ * conceder. Os limites são verificados nas atribuições e na avaliação do PDP, e cada
+ * tentativa de os ultrapassar é registada como um incidente de segurança de severidade alta.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limites dos limites de permissões
+const (
+	MaxPermissionBoundaryPatterns = 500
+	// Padrão que abrange todas as permissões
+	PermissionBoundaryWildcard = "*"
+)
+
+// permissionBoundaryPatternPattern valida os padrões: um código de permissão, "*" ou um
+// prefixo de segmentos terminado em ":*" (por exemplo, "roles:*")
+var permissionBoundaryPatternPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(:[A-Za-z0-9_.-]+)*(:\*)?$`)
+
+// PermissionBoundarySubjectType representa o tipo do titular de um limite de permissões
+type PermissionBoundarySubjectType string
+
+// Tipos de titulares dos limites de permissões
+const (
+	// O limite restringe as permissões que a função pode deter, incluindo as herdadas
+	PermissionBoundarySubjectRole PermissionBoundarySubjectType = "role"
+	// O limite restringe as permissões que o usuário pode conceder e exercer como administrador delegado
+	PermissionBoundarySubjectDelegatedAdmin PermissionBoundarySubjectType = "delegated_admin"
+)
+
+// IsValid indica se o tipo de titular é conhecido
+func (t PermissionBoundarySubjectType) IsValid() bool {
+	return t == PermissionBoundarySubjectRole || t == PermissionBoundarySubjectDelegatedAdmin
+}
+
+// PermissionBoundary define o conjunto máximo de permissões de uma função ou de um administrador delegado
+// Cada titular tem no máximo um limite; os padrões aceitam o sufixo ":*" para abranger um módulo
+type PermissionBoundary struct {
+	ID          uuid.UUID                     `json:"id"`
+	TenantID    uuid.UUID                     `json:"tenant_id"`
+	SubjectType PermissionBoundarySubjectType `json:"subject_type"`
+	SubjectID   uuid.UUID                     `json:"subject_id"`
+	// Código atual da função titular, preenchido na leitura dos limites das funções
+	RoleCode    string    `json:"role_code,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	UpdatedBy   uuid.UUID `json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Normalize remove espaços e padrões repetidos, ordenando os padrões
+// O padrão "*:*", usado nas políticas para todas as permissões, é convertido em "*"
+func (b *PermissionBoundary) Normalize() {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Description = strings.TrimSpace(b.Description)
+
+	patterns := make([]string, 0, len(b.Permissions))
+	seen := make(map[string]bool, len(b.Permissions))
+	for _, pattern := range b.Permissions {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "*:*" {
+			pattern = PermissionBoundaryWildcard
+		}
+		if pattern != "" && !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+	b.Permissions = patterns
+}
+
+// Validate valida o titular, o nome e os padrões do limite
+// Um limite sem padrões é válido e não permite nenhuma permissão
+func (b *PermissionBoundary) Validate() error {
+	if b.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !b.SubjectType.IsValid() {
+		return fmt.Errorf("%w: tipo de titular %q desconhecido", ErrInvalidPermissionBoundary, b.SubjectType)
+	}
+	if b.SubjectID == uuid.Nil {
+		return fmt.Errorf("%w: titular obrigatório", ErrInvalidPermissionBoundary)
+	}
+	if b.Name == "" || len(b.Name) > 255 {
+		return fmt.Errorf("%w: o nome é obrigatório e tem no máximo 255 caracteres", ErrInvalidPermissionBoundary)
+	}
+	if len(b.Permissions) > MaxPermissionBoundaryPatterns {
+		return fmt.Errorf("%w: máximo %d padrões de permissões", ErrInvalidPermissionBoundary, MaxPermissionBoundaryPatterns)
+	}
+	for _, pattern := range b.Permissions {
+		if pattern != PermissionBoundaryWildcard && !permissionBoundaryPatternPattern.MatchString(pattern) {
+			return fmt.Errorf("%w: padrão de permissão inválido %q", ErrInvalidPermissionBoundary, pattern)
+		}
+	}
+	return nil
+}
+
+// Allows indica se a permissão está dentro do limite
+func (b *PermissionBoundary) Allows(code string) bool {
+	for _, pattern := range b.Permissions {
+		if PermissionPatternMatches(pattern, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Exceeding retorna, ordenados e sem repetições, os códigos que ficam fora do limite
+func (b *PermissionBoundary) Exceeding(codes []string) []string {
+	var exceeding []string
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if !seen[code] && !b.Allows(code) {
+			exceeding = append(exceeding, code)
+		}
+		seen[code] = true
+	}
+	sort.Strings(exceeding)
+	return exceeding
+}
+
+// PermissionPatternMatches indica se o código de permissão corresponde ao padrão do limite
+// A mesma correspondência é aplicada pela política de autorização (boundary_pattern_matches)
+func PermissionPatternMatches(pattern, code string) bool {
+	if pattern == PermissionBoundaryWildcard || pattern == code {
+		return true
+	}
+	if strings.HasSuffix(pattern, ":*") {
+		return strings.HasPrefix(code, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// PermissionBoundaryPolicyInput representa os limites aplicáveis a um pedido, expostos às
+// políticas em input.user.permission_boundaries
+type PermissionBoundaryPolicyInput struct {
+	// Limite do usuário como administrador delegado; nulo quando o usuário não tem limite e
+	// vazio, mas não nulo, quando o limite não permite nenhuma permissão
+	User []string `json:"user"`
+	// Padrões das funções do usuário que têm limite, indexados pelo código da função
+	Roles map[string][]string `json:"roles"`
+}
+
+// PermissionBoundaryOperation identifica a operação em que um limite foi ultrapassado
+type PermissionBoundaryOperation string
+
+// Operações verificadas contra os limites de permissões
+const (
+	PermissionBoundaryOperationAssignPermission PermissionBoundaryOperation = "assign_permission"
+	PermissionBoundaryOperationAssignChildRole  PermissionBoundaryOperation = "assign_child_role"
+	PermissionBoundaryOperationAssignUser       PermissionBoundaryOperation = "assign_user"
+	PermissionBoundaryOperationAuthorization    PermissionBoundaryOperation = "authorization"
+)
+
+// PermissionBoundaryViolation descreve uma tentativa de ultrapassar um ou mais limites de permissões
+type PermissionBoundaryViolation struct {
+	TenantID  uuid.UUID                   `json:"tenant_id"`
+	ActorID   *uuid.UUID                  `json:"actor_id,omitempty"`
+	Operation PermissionBoundaryOperation `json:"operation"`
+	// Limites ultrapassados
+	BoundaryIDs []uuid.UUID `json:"boundary_ids,omitempty"`
+	// Função alvo da operação
+	RoleID   *uuid.UUID `json:"role_id,omitempty"`
+	RoleCode string     `json:"role_code,omitempty"`
+	// Usuários ou permissões afetados pela operação
+	SubjectIDs []uuid.UUID `json:"subject_ids,omitempty"`
+	// Permissões fora dos limites
+	Permissions []string `json:"permissions"`
+	// Pedido recusado pelo PDP, no formato "MÉTODO caminho"
+	Resource   string    `json:"resource,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewPermissionBoundaryIncident cria o incidente de segurança, de severidade alta, de uma violação
+func NewPermissionBoundaryIncident(violation *PermissionBoundaryViolation, now time.Time) (*SecurityIncident, error) {
+	summary := fmt.Sprintf("Tentativa de ultrapassar limite de permissões (%s): %s",
+		violation.Operation, strings.Join(violation.Permissions, ", "))
+	if violation.RoleCode != "" {
+		summary += fmt.Sprintf(" na função %s", violation.RoleCode)
+	}
+	if violation.Resource != "" {
+		summary += fmt.Sprintf(" em %s", violation.Resource)
+	}
+
+	occurredAt := violation.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+	evidence := []SecurityIncidentEvidence{{
+		EventType:  "permission_boundary." + string(violation.Operation),
+		OccurredAt: occurredAt,
+		RoleID:     violation.RoleID,
+		RoleCode:   violation.RoleCode,
+		SubjectIDs: violation.SubjectIDs,
+	}}
+
+	return NewSecurityIncident(
+		violation.TenantID,
+		SecurityIncidentRulePermissionBoundaryViolation,
+		SecurityIncidentSeverityHigh,
+		violation.ActorID,
+		summary,
+		evidence,
+		now,
+	)
+}
+
+// Erros específicos dos limites de permissões
+var (
+	ErrPermissionBoundaryNotFound        = errors.New("limite de permissões não encontrado")
+	ErrPermissionBoundarySubjectNotFound = errors.New("titular do limite de permissões não encontrado")
+	ErrPermissionBoundaryConflict        = errors.New("o titular já tem um limite de permissões")
+	ErrInvalidPermissionBoundary         = errors.New("limite de permissões inválido")
+	ErrPermissionBoundaryViolation       = errors.New("operação ultrapassa o limite de permissões")
+)
@@ -0,0 +1,73 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Decisões de autorização do PDP registadas para auditoria.
+ * As negações são sempre registadas; as permissões são amostradas, e cada decisão guarda a taxa
+ * de amostragem aplicada para permitir estimar o volume real de acessos.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Erros das decisões de autorização
+var (
+	ErrInvalidPermissionDecisionFilter = errors.New("filtro de decisões de autorização inválido")
+)
+
+// PermissionDecision representa uma decisão de autorização tomada pelo PDP
+type PermissionDecision struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	// Operação pedida (método HTTP) e recurso acedido (caminho do pedido)
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Allowed  bool   `json:"allowed"`
+	// Política avaliada pelo PDP
+	PolicyPath string `json:"policy_path,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	ClientIP   string `json:"client_ip,omitempty"`
+	// Probabilidade com que a decisão foi registada; cada registo representa 1/SampleRate decisões
+	SampleRate float64   `json:"sample_rate"`
+	DecidedAt  time.Time `json:"decided_at"`
+}
+
+// PermissionDecisionFilter restringe a consulta das decisões de autorização
+type PermissionDecisionFilter struct {
+	UserID *uuid.UUID
+	// Recurso exato ou, terminado em "*", prefixo do recurso
+	Resource string
+	Allowed  *bool
+	// Intervalo [From, To) da decisão
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
+
+// Validate verifica a coerência do intervalo de datas do filtro
+func (f PermissionDecisionFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: o início do intervalo deve ser anterior ao fim", ErrInvalidPermissionDecisionFilter)
+	}
+	if strings.Count(f.Resource, "*") > 1 || (strings.Contains(f.Resource, "*") && !strings.HasSuffix(f.Resource, "*")) {
+		return fmt.Errorf("%w: o recurso só admite \"*\" no final", ErrInvalidPermissionDecisionFilter)
+	}
+	return nil
+}
+
+// ResourcePrefix indica se o filtro de recurso é um prefixo e retorna o recurso sem o "*" final
+func (f PermissionDecisionFilter) ResourcePrefix() (string, bool) {
+	if strings.HasSuffix(f.Resource, "*") {
+		return strings.TrimSuffix(f.Resource, "*"), true
+	}
+	return f.Resource, false
+}
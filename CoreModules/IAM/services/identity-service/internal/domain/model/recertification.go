@@ -0,0 +1,417 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Campanhas de recertificação de acessos (access review).
+ * Uma campanha define o âmbito a rever (tenant, conjunto de funções e população de usuários),
+ * gera um item por atribuição de função e entrega cada item a um revisor, que aprova ou revoga
+ * o acesso até ao prazo. Os itens por decidir são escalados antes do prazo e revogados
+ * automaticamente no fim; a campanha concluída produz o relatório de atestação para auditoria.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecertificationCampaignStatus representa o estado de uma campanha de recertificação
+type RecertificationCampaignStatus string
+
+// Estados das campanhas: active → completed | cancelled
+const (
+	RecertificationCampaignActive    RecertificationCampaignStatus = "active"
+	RecertificationCampaignCompleted RecertificationCampaignStatus = "completed"
+	RecertificationCampaignCancelled RecertificationCampaignStatus = "cancelled"
+)
+
+// IsValid indica se o estado é conhecido
+func (s RecertificationCampaignStatus) IsValid() bool {
+	switch s {
+	case RecertificationCampaignActive, RecertificationCampaignCompleted, RecertificationCampaignCancelled:
+		return true
+	}
+	return false
+}
+
+// RecertificationItemStatus representa o estado de um item de revisão
+type RecertificationItemStatus string
+
+// Estados dos itens: pending → approved | revoked
+const (
+	RecertificationItemPending  RecertificationItemStatus = "pending"
+	RecertificationItemApproved RecertificationItemStatus = "approved"
+	RecertificationItemRevoked  RecertificationItemStatus = "revoked"
+)
+
+// IsValid indica se o estado é conhecido
+func (s RecertificationItemStatus) IsValid() bool {
+	switch s {
+	case RecertificationItemPending, RecertificationItemApproved, RecertificationItemRevoked:
+		return true
+	}
+	return false
+}
+
+// RecertificationDecision representa a decisão de um revisor sobre um item
+type RecertificationDecision string
+
+// Decisões dos revisores
+const (
+	RecertificationDecisionApprove RecertificationDecision = "approve"
+	RecertificationDecisionRevoke  RecertificationDecision = "revoke"
+)
+
+// Comentário registado nos itens revogados automaticamente no fim do prazo
+const RecertificationAutoRevokeComment = "acesso não recertificado dentro do prazo da campanha"
+
+// Limites das campanhas de recertificação
+const (
+	MaxRecertificationCampaignNameSize = 150
+	MaxRecertificationScopeRoles       = 100
+	MaxRecertificationScopeUsers       = 10000
+	MaxRecertificationCampaignItems    = 50000
+	MinRecertificationRevokeComment    = 10
+)
+
+// Erros das campanhas de recertificação
+var (
+	ErrRecertificationCampaignNotFound = errors.New("campanha de recertificação não encontrada")
+	ErrInvalidRecertificationCampaign  = errors.New("campanha de recertificação inválida")
+	ErrRecertificationCampaignState    = errors.New("o estado da campanha de recertificação não permite a operação")
+	ErrRecertificationEmptyScope       = errors.New("o âmbito da campanha não contém atribuições a rever")
+	ErrRecertificationItemNotFound     = errors.New("item de recertificação não encontrado")
+	ErrInvalidRecertificationDecision  = errors.New("decisão de recertificação inválida")
+	ErrRecertificationItemDecided      = errors.New("o item de recertificação já foi decidido")
+	ErrRecertificationNotReviewer      = errors.New("o usuário não é o revisor do item de recertificação")
+	ErrRecertificationSelfReview       = errors.New("o usuário não pode recertificar os próprios acessos")
+)
+
+// RecertificationCampaign representa uma campanha de revisão dos acessos de um tenant
+type RecertificationCampaign struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	// RoleIDs é o conjunto de funções cujas atribuições são revistas
+	RoleIDs []uuid.UUID `json:"role_ids"`
+	// UserIDs limita a revisão a uma população de usuários; vazio abrange todos os titulares
+	UserIDs []uuid.UUID `json:"user_ids,omitempty"`
+	// DefaultReviewerID revê os itens das funções sem dono; EscalationReviewerID recebe os itens
+	// por decidir na data de escalamento
+	DefaultReviewerID    uuid.UUID                     `json:"default_reviewer_id"`
+	EscalationReviewerID uuid.UUID                     `json:"escalation_reviewer_id"`
+	Status               RecertificationCampaignStatus `json:"status"`
+	ItemCount            int                           `json:"item_count"`
+	EscalateAt           time.Time                     `json:"escalate_at"`
+	Deadline             time.Time                     `json:"deadline"`
+	EscalatedAt          *time.Time                    `json:"escalated_at,omitempty"`
+	CreatedBy            uuid.UUID                     `json:"created_by"`
+	CreatedAt            time.Time                     `json:"created_at"`
+	CompletedAt          *time.Time                    `json:"completed_at,omitempty"`
+	CancelledBy          *uuid.UUID                    `json:"cancelled_by,omitempty"`
+	CancelledAt          *time.Time                    `json:"cancelled_at,omitempty"`
+	CancelReason         string                        `json:"cancel_reason,omitempty"`
+}
+
+// Validate verifica uma nova campanha
+func (c *RecertificationCampaign) Validate(now time.Time) error {
+	if c.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if c.Name == "" || len([]rune(c.Name)) > MaxRecertificationCampaignNameSize {
+		return fmt.Errorf("%w: o nome é obrigatório e tem no máximo %d caracteres",
+			ErrInvalidRecertificationCampaign, MaxRecertificationCampaignNameSize)
+	}
+	if len(c.RoleIDs) == 0 || len(c.RoleIDs) > MaxRecertificationScopeRoles {
+		return fmt.Errorf("%w: a campanha abrange entre 1 e %d funções",
+			ErrInvalidRecertificationCampaign, MaxRecertificationScopeRoles)
+	}
+	if len(c.UserIDs) > MaxRecertificationScopeUsers {
+		return fmt.Errorf("%w: a população tem no máximo %d usuários",
+			ErrInvalidRecertificationCampaign, MaxRecertificationScopeUsers)
+	}
+	for _, id := range append(append([]uuid.UUID{}, c.RoleIDs...), c.UserIDs...) {
+		if id == uuid.Nil {
+			return fmt.Errorf("%w: identificador vazio no âmbito", ErrInvalidRecertificationCampaign)
+		}
+	}
+	if c.DefaultReviewerID == uuid.Nil || c.EscalationReviewerID == uuid.Nil {
+		return fmt.Errorf("%w: o revisor padrão e o revisor de escalamento são obrigatórios",
+			ErrInvalidRecertificationCampaign)
+	}
+	if c.DefaultReviewerID == c.EscalationReviewerID {
+		return fmt.Errorf("%w: o revisor de escalamento tem de ser diferente do revisor padrão",
+			ErrInvalidRecertificationCampaign)
+	}
+	if !c.Deadline.After(now) {
+		return fmt.Errorf("%w: o prazo tem de ser futuro", ErrInvalidRecertificationCampaign)
+	}
+	if !c.EscalateAt.After(now) || !c.EscalateAt.Before(c.Deadline) {
+		return fmt.Errorf("%w: o escalamento tem de ocorrer antes do prazo", ErrInvalidRecertificationCampaign)
+	}
+	return nil
+}
+
+// InScope indica se o usuário pertence à população da campanha
+func (c *RecertificationCampaign) InScope(userID uuid.UUID) bool {
+	if len(c.UserIDs) == 0 {
+		return true
+	}
+	for _, id := range c.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverdue indica se o prazo da campanha terminou
+func (c *RecertificationCampaign) IsOverdue(now time.Time) bool {
+	return !now.Before(c.Deadline)
+}
+
+// Complete conclui uma campanha ativa
+func (c *RecertificationCampaign) Complete(now time.Time) error {
+	if c.Status != RecertificationCampaignActive {
+		return ErrRecertificationCampaignState
+	}
+	c.Status = RecertificationCampaignCompleted
+	c.CompletedAt = &now
+	return nil
+}
+
+// Cancel cancela uma campanha ativa; os itens por decidir deixam de ser revogados no prazo
+func (c *RecertificationCampaign) Cancel(actorID uuid.UUID, reason string, now time.Time) error {
+	if c.Status != RecertificationCampaignActive {
+		return ErrRecertificationCampaignState
+	}
+	if reason == "" {
+		return fmt.Errorf("%w: o motivo do cancelamento é obrigatório", ErrInvalidRecertificationCampaign)
+	}
+	c.Status = RecertificationCampaignCancelled
+	c.CancelledBy = &actorID
+	c.CancelledAt = &now
+	c.CancelReason = reason
+	return nil
+}
+
+// RecertificationItem representa a revisão de uma atribuição de função a um usuário
+type RecertificationItem struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	CampaignID uuid.UUID `json:"campaign_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	RoleID     uuid.UUID `json:"role_id"`
+	RoleCode   string    `json:"role_code"`
+	RoleName   string    `json:"role_name"`
+	// Dados da atribuição no momento em que a campanha foi gerada
+	AssignedAt          time.Time                 `json:"assigned_at"`
+	AssignedBy          uuid.UUID                 `json:"assigned_by"`
+	AssignmentExpiresAt *time.Time                `json:"assignment_expires_at,omitempty"`
+	ReviewerID          uuid.UUID                 `json:"reviewer_id"`
+	Status              RecertificationItemStatus `json:"status"`
+	Escalated           bool                      `json:"escalated"`
+	EscalatedAt         *time.Time                `json:"escalated_at,omitempty"`
+	// DecidedBy fica vazio nas revogações automáticas
+	DecidedBy   *uuid.UUID `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	AutoRevoked bool       `json:"auto_revoked"`
+	// RevokedAt indica quando a atribuição foi efetivamente retirada; RevocationError guarda a
+	// última falha, repetida pelas execuções agendadas
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	RevocationError string     `json:"revocation_error,omitempty"`
+}
+
+// Decide regista a decisão do revisor sobre um item por decidir
+// Só o revisor atribuído decide, nunca sobre os próprios acessos, e a revogação exige comentário
+func (i *RecertificationItem) Decide(reviewerID uuid.UUID, decision RecertificationDecision, comment string, now time.Time) error {
+	if i.Status != RecertificationItemPending {
+		return ErrRecertificationItemDecided
+	}
+	if reviewerID == i.UserID {
+		return ErrRecertificationSelfReview
+	}
+	if reviewerID != i.ReviewerID {
+		return ErrRecertificationNotReviewer
+	}
+
+	switch decision {
+	case RecertificationDecisionApprove:
+		i.Status = RecertificationItemApproved
+	case RecertificationDecisionRevoke:
+		if len([]rune(comment)) < MinRecertificationRevokeComment {
+			return fmt.Errorf("%w: a revogação exige um comentário com pelo menos %d caracteres",
+				ErrInvalidRecertificationDecision, MinRecertificationRevokeComment)
+		}
+		i.Status = RecertificationItemRevoked
+	default:
+		return fmt.Errorf("%w: decisão desconhecida %q", ErrInvalidRecertificationDecision, decision)
+	}
+	i.DecidedBy = &reviewerID
+	i.DecidedAt = &now
+	i.Comment = comment
+	return nil
+}
+
+// Escalate entrega um item por decidir ao revisor de escalamento
+// O item mantém o revisor quando o revisor de escalamento é o próprio titular do acesso
+func (i *RecertificationItem) Escalate(reviewerID uuid.UUID, now time.Time) error {
+	if i.Status != RecertificationItemPending {
+		return ErrRecertificationItemDecided
+	}
+	if reviewerID != i.UserID {
+		i.ReviewerID = reviewerID
+	}
+	i.Escalated = true
+	i.EscalatedAt = &now
+	return nil
+}
+
+// AutoRevoke revoga um item não decidido até ao prazo da campanha
+func (i *RecertificationItem) AutoRevoke(now time.Time) error {
+	if i.Status != RecertificationItemPending {
+		return ErrRecertificationItemDecided
+	}
+	i.Status = RecertificationItemRevoked
+	i.AutoRevoked = true
+	i.DecidedAt = &now
+	i.Comment = RecertificationAutoRevokeComment
+	return nil
+}
+
+// NeedsRevocation indica se a atribuição de um item revogado ainda não foi retirada
+func (i *RecertificationItem) NeedsRevocation() bool {
+	return i.Status == RecertificationItemRevoked && i.RevokedAt == nil
+}
+
+// RecertificationItemFilter restringe a listagem dos itens de recertificação
+type RecertificationItemFilter struct {
+	CampaignID *uuid.UUID
+	ReviewerID *uuid.UUID
+	Status     RecertificationItemStatus
+}
+
+// RecertificationSummary contabiliza os itens de uma campanha
+type RecertificationSummary struct {
+	Total       int `json:"total"`
+	Pending     int `json:"pending"`
+	Approved    int `json:"approved"`
+	Revoked     int `json:"revoked"`
+	AutoRevoked int `json:"auto_revoked"`
+	Escalated   int `json:"escalated"`
+	// RevocationsPending conta os itens revogados cuja atribuição ainda não foi retirada
+	RevocationsPending int `json:"revocations_pending"`
+}
+
+// RecertificationReviewerSummary contabiliza as decisões de um revisor
+type RecertificationReviewerSummary struct {
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+	Assigned   int       `json:"assigned"`
+	Approved   int       `json:"approved"`
+	Revoked    int       `json:"revoked"`
+	Pending    int       `json:"pending"`
+}
+
+// SummarizeRecertificationItems contabiliza os itens e as decisões de cada revisor
+// Os revisores são ordenados pelo número de itens atribuídos
+func SummarizeRecertificationItems(items []*RecertificationItem) (RecertificationSummary, []RecertificationReviewerSummary) {
+	summary := RecertificationSummary{Total: len(items)}
+	byReviewer := make(map[uuid.UUID]*RecertificationReviewerSummary)
+	for _, item := range items {
+		reviewer, ok := byReviewer[item.ReviewerID]
+		if !ok {
+			reviewer = &RecertificationReviewerSummary{ReviewerID: item.ReviewerID}
+			byReviewer[item.ReviewerID] = reviewer
+		}
+		reviewer.Assigned++
+
+		switch item.Status {
+		case RecertificationItemPending:
+			summary.Pending++
+			reviewer.Pending++
+		case RecertificationItemApproved:
+			summary.Approved++
+			reviewer.Approved++
+		case RecertificationItemRevoked:
+			summary.Revoked++
+			if item.AutoRevoked {
+				summary.AutoRevoked++
+			} else {
+				reviewer.Revoked++
+			}
+		}
+		if item.Escalated {
+			summary.Escalated++
+		}
+		if item.NeedsRevocation() {
+			summary.RevocationsPending++
+		}
+	}
+
+	reviewers := make([]RecertificationReviewerSummary, 0, len(byReviewer))
+	for _, reviewer := range byReviewer {
+		reviewers = append(reviewers, *reviewer)
+	}
+	sort.Slice(reviewers, func(a, b int) bool {
+		if reviewers[a].Assigned != reviewers[b].Assigned {
+			return reviewers[a].Assigned > reviewers[b].Assigned
+		}
+		return reviewers[a].ReviewerID.String() < reviewers[b].ReviewerID.String()
+	})
+	return summary, reviewers
+}
+
+// RecertificationAttestation é o relatório final de uma campanha concluída, entregue aos auditores
+// Digest é o SHA-256 do relatório serializado sem o próprio digest, para verificar a sua integridade
+type RecertificationAttestation struct {
+	Campaign    *RecertificationCampaign         `json:"campaign"`
+	Summary     RecertificationSummary           `json:"summary"`
+	Reviewers   []RecertificationReviewerSummary `json:"reviewers"`
+	Items       []*RecertificationItem           `json:"items"`
+	GeneratedAt time.Time                        `json:"generated_at"`
+	Digest      string                           `json:"digest"`
+}
+
+// NewRecertificationAttestation monta o relatório de atestação de uma campanha concluída
+func NewRecertificationAttestation(campaign *RecertificationCampaign, items []*RecertificationItem, now time.Time) (*RecertificationAttestation, error) {
+	if campaign.Status != RecertificationCampaignCompleted {
+		return nil, fmt.Errorf("%w: a atestação só existe para campanhas concluídas", ErrRecertificationCampaignState)
+	}
+
+	summary, reviewers := SummarizeRecertificationItems(items)
+	attestation := &RecertificationAttestation{
+		Campaign:    campaign,
+		Summary:     summary,
+		Reviewers:   reviewers,
+		Items:       items,
+		GeneratedAt: now.UTC().Truncate(time.Second),
+	}
+	digest, err := attestation.ComputeDigest()
+	if err != nil {
+		return nil, err
+	}
+	attestation.Digest = digest
+	return attestation, nil
+}
+
+// ComputeDigest calcula o SHA-256 do relatório serializado em JSON com o digest vazio
+func (a *RecertificationAttestation) ComputeDigest() (string, error) {
+	unsigned := *a
+	unsigned.Digest = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar atestação da campanha: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
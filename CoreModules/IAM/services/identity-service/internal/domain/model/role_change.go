@@ -0,0 +1,110 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Notificações em tempo real das alterações das funções e das suas atribuições.
+ * As notificações são derivadas dos eventos de domínio publicados no barramento
+ * e entregues às subscriptions GraphQL das interfaces de administração.
+ */
+
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Erros das subscriptions das alterações de funções
+var (
+	ErrRoleChangeSubscriptionLimit = errors.New("limite de subscriptions das alterações de funções atingido")
+	ErrRoleChangeFeedClosed        = errors.New("fluxo das alterações de funções encerrado")
+)
+
+// RoleChangeType identifica o tipo de alteração de uma função
+type RoleChangeType string
+
+// Tipos de alteração das funções e das suas atribuições
+const (
+	RoleChangeCreated             RoleChangeType = "ROLE_CREATED"
+	RoleChangeUpdated             RoleChangeType = "ROLE_UPDATED"
+	RoleChangeDeleted             RoleChangeType = "ROLE_DELETED"
+	RoleChangePermissionsAssigned RoleChangeType = "PERMISSIONS_ASSIGNED"
+	RoleChangePermissionsRevoked  RoleChangeType = "PERMISSIONS_REVOKED"
+	RoleChangeUsersAssigned       RoleChangeType = "USERS_ASSIGNED"
+	RoleChangeUsersRevoked        RoleChangeType = "USERS_REVOKED"
+)
+
+// IsAssignment indica se a alteração diz respeito às permissões ou aos usuários da função
+func (t RoleChangeType) IsAssignment() bool {
+	switch t {
+	case RoleChangePermissionsAssigned, RoleChangePermissionsRevoked, RoleChangeUsersAssigned, RoleChangeUsersRevoked:
+		return true
+	}
+	return false
+}
+
+// RoleChangeNotification descreve uma alteração de uma função entregue às subscriptions
+type RoleChangeNotification struct {
+	ID            uuid.UUID      `json:"id"`
+	Type          RoleChangeType `json:"type"`
+	TenantID      uuid.UUID      `json:"tenant_id"`
+	RoleID        uuid.UUID      `json:"role_id"`
+	RoleCode      string         `json:"role_code"`
+	RoleName      string         `json:"role_name,omitempty"`
+	IsActive      *bool          `json:"is_active,omitempty"`
+	HardDeleted   bool           `json:"hard_deleted,omitempty"`
+	PermissionIDs []uuid.UUID    `json:"permission_ids,omitempty"`
+	UserIDs       []uuid.UUID    `json:"user_ids,omitempty"`
+	ActorID       *uuid.UUID     `json:"actor_id,omitempty"`
+	OccurredAt    time.Time      `json:"occurred_at"`
+}
+
+// RoleChangeFilter seleciona as notificações entregues a uma subscription
+// TenantID nulo aceita todos os tenants e só deve ser usado com acesso entre tenants;
+// UserID seleciona as atribuições e revogações que incluem o usuário
+type RoleChangeFilter struct {
+	TenantID uuid.UUID        `json:"tenant_id"`
+	RoleID   *uuid.UUID       `json:"role_id,omitempty"`
+	UserID   *uuid.UUID       `json:"user_id,omitempty"`
+	Types    []RoleChangeType `json:"types,omitempty"`
+}
+
+// Matches indica se a notificação satisfaz o filtro
+func (f RoleChangeFilter) Matches(notification *RoleChangeNotification) bool {
+	if f.TenantID != uuid.Nil && notification.TenantID != f.TenantID {
+		return false
+	}
+	if f.RoleID != nil && notification.RoleID != *f.RoleID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		matched := false
+		for _, changeType := range f.Types {
+			if changeType == notification.Type {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.UserID != nil {
+		for _, userID := range notification.UserIDs {
+			if userID == *f.UserID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// RoleChangeSubscriber identifica o usuário autenticado que abre uma subscription
+// As quotas de subscriptions são contadas por tenant e por usuário do subscritor
+type RoleChangeSubscriber struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
@@ -0,0 +1,309 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Modelo de domínio para o histórico imutável de funções (roles).
+ * Cada alteração de uma função é registada como evento numa sequência append-only;
+ * o estado da função em qualquer instante é reconstruído pela aplicação dos eventos.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoleHistoryEventType define os tipos de eventos do histórico de funções
+type RoleHistoryEventType string
+
+const (
+	// RoleHistoryCreated registra a criação da função com o seu estado inicial
+	RoleHistoryCreated RoleHistoryEventType = "ROLE_CREATED"
+
+	// RoleHistoryUpdated registra a alteração de atributos da função
+	RoleHistoryUpdated RoleHistoryEventType = "ROLE_UPDATED"
+
+	// RoleHistoryDeleted registra a exclusão da função
+	RoleHistoryDeleted RoleHistoryEventType = "ROLE_DELETED"
+
+	// RoleHistoryPermissionsAssigned registra permissões atribuídas à função
+	RoleHistoryPermissionsAssigned RoleHistoryEventType = "PERMISSIONS_ASSIGNED"
+
+	// RoleHistoryPermissionsRevoked registra permissões revogadas da função
+	RoleHistoryPermissionsRevoked RoleHistoryEventType = "PERMISSIONS_REVOKED"
+
+	// RoleHistoryParentAssigned registra a inclusão da função como filha de outra
+	RoleHistoryParentAssigned RoleHistoryEventType = "PARENT_ASSIGNED"
+
+	// RoleHistoryParentRemoved registra a remoção da função como filha de outra
+	RoleHistoryParentRemoved RoleHistoryEventType = "PARENT_REMOVED"
+)
+
+// Erros do histórico de funções
+var (
+	ErrRoleHistoryNotFound        = errors.New("histórico da função não encontrado")
+	ErrRoleHistoryVersionConflict = errors.New("conflito de versão no histórico da função")
+	ErrRoleHistoryInvalidEvent    = errors.New("evento inválido para o histórico da função")
+)
+
+// RoleHistoryPayload contém os dados de um evento do histórico
+// Apenas os campos relevantes para o tipo de evento são preenchidos
+type RoleHistoryPayload struct {
+	Code          *string                `json:"code,omitempty"`
+	Name          *string                `json:"name,omitempty"`
+	Description   *string                `json:"description,omitempty"`
+	Type          *RoleType              `json:"type,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	Priority      *int                   `json:"priority,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	PermissionIDs []uuid.UUID            `json:"permission_ids,omitempty"`
+	ParentID      *uuid.UUID             `json:"parent_id,omitempty"`
+}
+
+// RoleHistoryEvent representa um evento imutável do histórico de uma função
+type RoleHistoryEvent struct {
+	// ID único do evento
+	ID uuid.UUID `json:"id"`
+
+	// TenantID identifica o tenant da função
+	TenantID uuid.UUID `json:"tenant_id"`
+
+	// RoleID identifica a função (agregado) à qual o evento pertence
+	RoleID uuid.UUID `json:"role_id"`
+
+	// Version é a posição do evento na sequência da função, iniciando em 1
+	Version int64 `json:"version"`
+
+	// Type indica o tipo do evento
+	Type RoleHistoryEventType `json:"type"`
+
+	// Payload contém os dados alterados pelo evento
+	Payload RoleHistoryPayload `json:"payload"`
+
+	// ActorID identifica o autor da alteração (opcional)
+	ActorID *uuid.UUID `json:"actor_id,omitempty"`
+
+	// OccurredAt registra quando a alteração ocorreu
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// RoleState representa o estado reconstruído de uma função num determinado instante
+type RoleState struct {
+	RoleID        uuid.UUID              `json:"role_id"`
+	TenantID      uuid.UUID              `json:"tenant_id"`
+	Code          string                 `json:"code"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Type          RoleType               `json:"type"`
+	IsActive      bool                   `json:"is_active"`
+	Priority      int                    `json:"priority"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	PermissionIDs []uuid.UUID            `json:"permission_ids"`
+	ParentIDs     []uuid.UUID            `json:"parent_ids"`
+	Deleted       bool                   `json:"deleted"`
+	Version       int64                  `json:"version"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// NewRoleState cria o estado vazio de uma função, antes de qualquer evento
+func NewRoleState(tenantID, roleID uuid.UUID) *RoleState {
+	return &RoleState{
+		RoleID:        roleID,
+		TenantID:      tenantID,
+		PermissionIDs: []uuid.UUID{},
+		ParentIDs:     []uuid.UUID{},
+	}
+}
+
+// Apply aplica um evento ao estado, que deve ser o próximo na sequência da função
+func (s *RoleState) Apply(evt *RoleHistoryEvent) error {
+	if evt.RoleID != s.RoleID || evt.TenantID != s.TenantID {
+		return fmt.Errorf("%w: evento %s não pertence à função %s", ErrRoleHistoryInvalidEvent, evt.ID, s.RoleID)
+	}
+	if evt.Version != s.Version+1 {
+		return fmt.Errorf("%w: esperada versão %d, recebida %d", ErrRoleHistoryVersionConflict, s.Version+1, evt.Version)
+	}
+
+	p := evt.Payload
+	switch evt.Type {
+	case RoleHistoryCreated, RoleHistoryUpdated:
+		if p.Code != nil {
+			s.Code = *p.Code
+		}
+		if p.Name != nil {
+			s.Name = *p.Name
+		}
+		if p.Description != nil {
+			s.Description = *p.Description
+		}
+		if p.Type != nil {
+			s.Type = *p.Type
+		}
+		if p.IsActive != nil {
+			s.IsActive = *p.IsActive
+		}
+		if p.Priority != nil {
+			s.Priority = *p.Priority
+		}
+		if p.Metadata != nil {
+			s.Metadata = copyMetadata(p.Metadata)
+		}
+		if evt.Type == RoleHistoryCreated {
+			s.Deleted = false
+		}
+	case RoleHistoryDeleted:
+		s.Deleted = true
+		s.IsActive = false
+	case RoleHistoryPermissionsAssigned:
+		s.PermissionIDs = addIDs(s.PermissionIDs, p.PermissionIDs...)
+	case RoleHistoryPermissionsRevoked:
+		s.PermissionIDs = removeIDs(s.PermissionIDs, p.PermissionIDs...)
+	case RoleHistoryParentAssigned, RoleHistoryParentRemoved:
+		if p.ParentID == nil {
+			return fmt.Errorf("%w: evento %s sem função pai", ErrRoleHistoryInvalidEvent, evt.Type)
+		}
+		if evt.Type == RoleHistoryParentAssigned {
+			s.ParentIDs = addIDs(s.ParentIDs, *p.ParentID)
+		} else {
+			s.ParentIDs = removeIDs(s.ParentIDs, *p.ParentID)
+		}
+	default:
+		return fmt.Errorf("%w: tipo desconhecido %s", ErrRoleHistoryInvalidEvent, evt.Type)
+	}
+
+	s.Version = evt.Version
+	s.UpdatedAt = evt.OccurredAt
+	return nil
+}
+
+// Clone cria uma cópia independente do estado
+func (s *RoleState) Clone() *RoleState {
+	clone := *s
+	clone.Metadata = copyMetadata(s.Metadata)
+	clone.PermissionIDs = append([]uuid.UUID{}, s.PermissionIDs...)
+	clone.ParentIDs = append([]uuid.UUID{}, s.ParentIDs...)
+	return &clone
+}
+
+// RoleSnapshot representa o estado materializado de uma função numa versão
+// Evita reaplicar todo o histórico ao reconstruir funções com muitos eventos
+type RoleSnapshot struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	RoleID   uuid.UUID `json:"role_id"`
+	Version  int64     `json:"version"`
+	State    RoleState `json:"state"`
+
+	// TakenAt corresponde ao instante do último evento incluído no snapshot
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// RoleFieldChange descreve a alteração de um atributo da função entre dois estados
+type RoleFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// RoleStateDiff descreve as diferenças de uma função entre dois instantes
+type RoleStateDiff struct {
+	RoleID             uuid.UUID         `json:"role_id"`
+	From               time.Time         `json:"from"`
+	To                 time.Time         `json:"to"`
+	FromVersion        int64             `json:"from_version"`
+	ToVersion          int64             `json:"to_version"`
+	Changes            []RoleFieldChange `json:"changes"`
+	AddedPermissions   []uuid.UUID       `json:"added_permissions"`
+	RemovedPermissions []uuid.UUID       `json:"removed_permissions"`
+	AddedParents       []uuid.UUID       `json:"added_parents"`
+	RemovedParents     []uuid.UUID       `json:"removed_parents"`
+}
+
+// DiffRoleStates compara dois estados da mesma função
+func DiffRoleStates(before, after *RoleState) *RoleStateDiff {
+	diff := &RoleStateDiff{
+		RoleID:      after.RoleID,
+		FromVersion: before.Version,
+		ToVersion:   after.Version,
+		Changes:     []RoleFieldChange{},
+	}
+
+	fields := []struct {
+		name          string
+		before, after interface{}
+	}{
+		{"code", before.Code, after.Code},
+		{"name", before.Name, after.Name},
+		{"description", before.Description, after.Description},
+		{"type", before.Type, after.Type},
+		{"is_active", before.IsActive, after.IsActive},
+		{"priority", before.Priority, after.Priority},
+		{"metadata", before.Metadata, after.Metadata},
+		{"deleted", before.Deleted, after.Deleted},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.before, f.after) {
+			diff.Changes = append(diff.Changes, RoleFieldChange{Field: f.name, Before: f.before, After: f.after})
+		}
+	}
+
+	diff.AddedPermissions = removeIDs(after.PermissionIDs, before.PermissionIDs...)
+	diff.RemovedPermissions = removeIDs(before.PermissionIDs, after.PermissionIDs...)
+	diff.AddedParents = removeIDs(after.ParentIDs, before.ParentIDs...)
+	diff.RemovedParents = removeIDs(before.ParentIDs, after.ParentIDs...)
+
+	return diff
+}
+
+// copyMetadata cria uma cópia rasa dos metadados
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
+
+// addIDs retorna o conjunto ordenado resultante da inclusão dos IDs
+func addIDs(ids []uuid.UUID, added ...uuid.UUID) []uuid.UUID {
+	set := make(map[uuid.UUID]bool, len(ids)+len(added))
+	for _, id := range ids {
+		set[id] = true
+	}
+	for _, id := range added {
+		set[id] = true
+	}
+	return sortedIDs(set)
+}
+
+// removeIDs retorna o conjunto ordenado resultante da remoção dos IDs
+func removeIDs(ids []uuid.UUID, removed ...uuid.UUID) []uuid.UUID {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	for _, id := range removed {
+		delete(set, id)
+	}
+	return sortedIDs(set)
+}
+
+// sortedIDs converte um conjunto de IDs numa lista ordenada
+func sortedIDs(set map[uuid.UUID]bool) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
@@ -0,0 +1,192 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Políticas de acesso aos campos dos metadados das funções.
+ * Os metadados podem guardar informação operacional sensível (por exemplo, notas
+ * regulatórias); cada chave protegida é definida no catálogo de permissões por uma
+ * permissão de leitura e/ou de escrita com o código "roles:metadata.<chave>:<ação>".
+ */
+
+package model
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Erros das políticas dos metadados das funções
+var (
+	ErrRoleMetadataWriteDenied = errors.New("sem permissão para alterar campos protegidos dos metadados da função")
+)
+
+// Códigos do catálogo de permissões que definem as políticas dos metadados
+const (
+	RoleMetadataPermissionPrefix = "roles:metadata."
+	RoleMetadataActionRead       = "read"
+	RoleMetadataActionWrite      = "write"
+)
+
+// RoleMetadataPolicy define as permissões exigidas para ler e alterar uma chave dos metadados
+// Uma permissão vazia indica que a ação não está restrita
+type RoleMetadataPolicy struct {
+	Key             string `json:"key"`
+	ReadPermission  string `json:"read_permission,omitempty"`
+	WritePermission string `json:"write_permission,omitempty"`
+}
+
+// RoleMetadataPermissionCode retorna o código da permissão do catálogo para a chave e a ação
+func RoleMetadataPermissionCode(key, action string) string {
+	return RoleMetadataPermissionPrefix + key + ":" + action
+}
+
+// ParseRoleMetadataPermissionCode extrai a chave e a ação de um código de permissão dos metadados
+func ParseRoleMetadataPermissionCode(code string) (key, action string, ok bool) {
+	if !strings.HasPrefix(code, RoleMetadataPermissionPrefix) {
+		return "", "", false
+	}
+	separator := strings.LastIndex(code, ":")
+	if separator <= len(RoleMetadataPermissionPrefix) {
+		return "", "", false
+	}
+	key, action = code[len(RoleMetadataPermissionPrefix):separator], code[separator+1:]
+	if action != RoleMetadataActionRead && action != RoleMetadataActionWrite {
+		return "", "", false
+	}
+	return key, action, true
+}
+
+// BuildRoleMetadataPolicies agrupa por chave os códigos de permissão dos metadados, ordenados por chave
+// Os códigos que não seguem o formato das permissões dos metadados são ignorados
+func BuildRoleMetadataPolicies(permissionCodes []string) []*RoleMetadataPolicy {
+	byKey := make(map[string]*RoleMetadataPolicy)
+	for _, code := range permissionCodes {
+		key, action, ok := ParseRoleMetadataPermissionCode(code)
+		if !ok {
+			continue
+		}
+		policy, exists := byKey[key]
+		if !exists {
+			policy = &RoleMetadataPolicy{Key: key}
+			byKey[key] = policy
+		}
+		if action == RoleMetadataActionRead {
+			policy.ReadPermission = code
+		} else {
+			policy.WritePermission = code
+		}
+	}
+
+	policies := make([]*RoleMetadataPolicy, 0, len(byKey))
+	for _, policy := range byKey {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Key < policies[j].Key })
+	return policies
+}
+
+// Permissions retorna as permissões exigidas pela política
+func (p *RoleMetadataPolicy) Permissions() []string {
+	var permissions []string
+	if p.ReadPermission != "" {
+		permissions = append(permissions, p.ReadPermission)
+	}
+	if p.WritePermission != "" {
+		permissions = append(permissions, p.WritePermission)
+	}
+	return permissions
+}
+
+// RoleMetadataAccess indica as chaves protegidas dos metadados que um usuário pode ler e alterar
+// As chaves sem política são livres; uma chave com permissão de leitura mas sem permissão de
+// escrita só pode ser alterada por quem a pode ler. Um acesso nulo não aplica restrições
+type RoleMetadataAccess struct {
+	policies map[string]*RoleMetadataPolicy
+	granted  map[string]bool
+}
+
+// NewRoleMetadataAccess cria o acesso de um usuário a partir das políticas e das permissões que detém
+func NewRoleMetadataAccess(policies []*RoleMetadataPolicy, grantedPermissions []string) *RoleMetadataAccess {
+	access := &RoleMetadataAccess{
+		policies: make(map[string]*RoleMetadataPolicy, len(policies)),
+		granted:  make(map[string]bool, len(grantedPermissions)),
+	}
+	for _, policy := range policies {
+		access.policies[policy.Key] = policy
+	}
+	for _, code := range grantedPermissions {
+		access.granted[code] = true
+	}
+	return access
+}
+
+// CanRead indica se o usuário pode ler a chave
+func (a *RoleMetadataAccess) CanRead(key string) bool {
+	if a == nil {
+		return true
+	}
+	policy, ok := a.policies[key]
+	if !ok || policy.ReadPermission == "" {
+		return true
+	}
+	return a.granted[policy.ReadPermission]
+}
+
+// CanWrite indica se o usuário pode alterar a chave
+func (a *RoleMetadataAccess) CanWrite(key string) bool {
+	if a == nil {
+		return true
+	}
+	policy, ok := a.policies[key]
+	if !ok {
+		return true
+	}
+	if policy.WritePermission != "" {
+		return a.granted[policy.WritePermission]
+	}
+	return a.CanRead(key)
+}
+
+// FilterReadable retorna uma cópia dos metadados sem as chaves que o usuário não pode ler
+func (a *RoleMetadataAccess) FilterReadable(metadata map[string]interface{}) map[string]interface{} {
+	if a == nil || metadata == nil {
+		return metadata
+	}
+	filtered := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		if a.CanRead(key) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// ApplyWrite combina os metadados pedidos com os atuais (nil numa criação)
+// As chaves que o usuário não pode alterar mantêm o valor atual, mesmo que omitidas no pedido,
+// para que quem não as vê não as apague; retorna as chaves cujo pedido as alteraria, ordenadas
+func (a *RoleMetadataAccess) ApplyWrite(current, requested map[string]interface{}) (map[string]interface{}, []string) {
+	var denied []string
+	merged := make(map[string]interface{}, len(requested))
+	for key, value := range requested {
+		if a.CanWrite(key) {
+			merged[key] = value
+			continue
+		}
+		if currentValue, exists := current[key]; !exists || !reflect.DeepEqual(currentValue, value) {
+			denied = append(denied, key)
+		}
+	}
+	for key, value := range current {
+		if !a.CanWrite(key) {
+			merged[key] = value
+		}
+	}
+
+	if requested == nil && len(merged) == 0 {
+		merged = nil
+	}
+	sort.Strings(denied)
+	return merged, denied
+}
@@ -0,0 +1,272 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Mineração de funções a partir das permissões atribuídas diretamente aos usuários.
+ * Os usuários são agrupados pela semelhança dos seus conjuntos de permissões diretas; cada grupo
+ * suficientemente grande dá origem a uma função candidata com as permissões partilhadas por todos,
+ * que um administrador pode aceitar (criando a função) ou descartar.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Estados de uma sugestão de função
+const (
+	RoleSuggestionStatusPending   = "pending"
+	RoleSuggestionStatusAccepted  = "accepted"
+	RoleSuggestionStatusDismissed = "dismissed"
+)
+
+// Chave de metadados gravada nas funções criadas a partir de uma sugestão
+const RoleMetadataSuggestionID = "role_suggestion_id"
+
+// Erros da mineração de funções
+var (
+	ErrRoleSuggestionNotFound   = errors.New("sugestão de função não encontrada")
+	ErrRoleSuggestionNotPending = errors.New("a sugestão de função já foi aceite ou descartada")
+	ErrInvalidRoleSuggestion    = errors.New("sugestão de função inválida")
+)
+
+// DirectPermissionAssignment representa uma permissão atribuída diretamente a um usuário
+type DirectPermissionAssignment struct {
+	UserID         uuid.UUID `json:"user_id"`
+	PermissionID   uuid.UUID `json:"permission_id"`
+	PermissionCode string    `json:"permission_code"`
+}
+
+// RoleSuggestion representa uma função candidata: um conjunto de permissões partilhado por vários usuários
+type RoleSuggestion struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	// Identifica o conjunto de permissões; sugestões aceites ou descartadas não voltam a ser propostas
+	Fingerprint     string      `json:"fingerprint"`
+	PermissionIDs   []uuid.UUID `json:"permission_ids"`
+	PermissionCodes []string    `json:"permission_codes"`
+	// Usuários que detêm diretamente todas as permissões da sugestão
+	UserIDs   []uuid.UUID `json:"user_ids"`
+	UserCount int         `json:"user_count"`
+	// Atribuições diretas que a função substituiria (usuários × permissões)
+	CoveredAssignments int `json:"covered_assignments"`
+	// Semelhança média (Jaccard) entre as permissões diretas dos usuários e as da sugestão
+	Cohesion    float64    `json:"cohesion"`
+	Status      string     `json:"status"`
+	RoleID      *uuid.UUID `json:"role_id,omitempty"`
+	ReviewedBy  *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+// Review regista a aceitação ou o descarte da sugestão
+func (s *RoleSuggestion) Review(status string, reviewedBy uuid.UUID, roleID *uuid.UUID, now time.Time) error {
+	if s.Status != RoleSuggestionStatusPending {
+		return ErrRoleSuggestionNotPending
+	}
+	s.Status = status
+	s.RoleID = roleID
+	s.ReviewedBy = &reviewedBy
+	s.ReviewedAt = &now
+	return nil
+}
+
+// RoleMiningParams controla o agrupamento dos usuários e a seleção das sugestões
+type RoleMiningParams struct {
+	// Número mínimo de usuários que devem partilhar as permissões
+	MinUsers int
+	// Número mínimo de permissões de uma sugestão
+	MinPermissions int
+	// Semelhança (Jaccard) mínima para juntar um conjunto de permissões a um grupo
+	MinSimilarity float64
+	// Número máximo de sugestões por tenant
+	MaxSuggestions int
+}
+
+// roleMiningCluster é um grupo de usuários com as permissões partilhadas por todos
+type roleMiningCluster struct {
+	core map[uuid.UUID]bool
+}
+
+// MineRoleSuggestions agrupa os usuários pelas permissões diretas e retorna as funções candidatas,
+// das que substituem mais atribuições diretas para as que substituem menos
+// Os conjuntos de permissões são considerados do mais frequente para o menos frequente; cada um
+// junta-se ao grupo mais semelhante, reduzindo as permissões do grupo às partilhadas, ou inicia um novo
+func MineRoleSuggestions(tenantID uuid.UUID, assignments []*DirectPermissionAssignment, params RoleMiningParams, now time.Time) []*RoleSuggestion {
+	userPermissions := make(map[uuid.UUID]map[uuid.UUID]bool)
+	permissionCodes := make(map[uuid.UUID]string)
+	for _, assignment := range assignments {
+		if userPermissions[assignment.UserID] == nil {
+			userPermissions[assignment.UserID] = make(map[uuid.UUID]bool)
+		}
+		userPermissions[assignment.UserID][assignment.PermissionID] = true
+		permissionCodes[assignment.PermissionID] = assignment.PermissionCode
+	}
+
+	// Usuários com conjuntos idênticos contam como um único conjunto com vários usuários
+	type permissionSet struct {
+		permissions map[uuid.UUID]bool
+		users       int
+		key         string
+	}
+	setsByKey := make(map[string]*permissionSet)
+	for _, permissions := range userPermissions {
+		key := permissionSetKey(permissions)
+		if set, ok := setsByKey[key]; ok {
+			set.users++
+			continue
+		}
+		setsByKey[key] = &permissionSet{permissions: permissions, users: 1, key: key}
+	}
+	sets := make([]*permissionSet, 0, len(setsByKey))
+	for _, set := range setsByKey {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].users != sets[j].users {
+			return sets[i].users > sets[j].users
+		}
+		if len(sets[i].permissions) != len(sets[j].permissions) {
+			return len(sets[i].permissions) > len(sets[j].permissions)
+		}
+		return sets[i].key < sets[j].key
+	})
+
+	var clusters []*roleMiningCluster
+	for _, set := range sets {
+		if len(set.permissions) < params.MinPermissions {
+			continue
+		}
+
+		var best *roleMiningCluster
+		bestSimilarity := 0.0
+		for _, cluster := range clusters {
+			similarity := jaccard(cluster.core, set.permissions)
+			if similarity >= params.MinSimilarity && similarity > bestSimilarity &&
+				intersectionSize(cluster.core, set.permissions) >= params.MinPermissions {
+				best, bestSimilarity = cluster, similarity
+			}
+		}
+
+		if best == nil {
+			core := make(map[uuid.UUID]bool, len(set.permissions))
+			for permissionID := range set.permissions {
+				core[permissionID] = true
+			}
+			clusters = append(clusters, &roleMiningCluster{core: core})
+			continue
+		}
+		for permissionID := range best.core {
+			if !set.permissions[permissionID] {
+				delete(best.core, permissionID)
+			}
+		}
+	}
+
+	// Cada grupo é avaliado sobre todos os usuários que detêm as suas permissões, dentro ou fora do grupo
+	seen := make(map[string]bool)
+	var suggestions []*RoleSuggestion
+	for _, cluster := range clusters {
+		fingerprint := permissionSetKey(cluster.core)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+
+		var (
+			userIDs  []uuid.UUID
+			cohesion float64
+		)
+		for userID, permissions := range userPermissions {
+			if intersectionSize(cluster.core, permissions) == len(cluster.core) {
+				userIDs = append(userIDs, userID)
+				cohesion += jaccard(cluster.core, permissions)
+			}
+		}
+		if len(userIDs) < params.MinUsers {
+			continue
+		}
+
+		suggestion := &RoleSuggestion{
+			ID:                 uuid.New(),
+			TenantID:           tenantID,
+			Fingerprint:        fingerprint,
+			UserIDs:            sortedUUIDs(userIDs),
+			UserCount:          len(userIDs),
+			CoveredAssignments: len(userIDs) * len(cluster.core),
+			Cohesion:           math.Round(cohesion/float64(len(userIDs))*10000) / 10000,
+			Status:             RoleSuggestionStatusPending,
+			GeneratedAt:        now,
+		}
+		for permissionID := range cluster.core {
+			suggestion.PermissionIDs = append(suggestion.PermissionIDs, permissionID)
+		}
+		suggestion.PermissionIDs = sortedUUIDs(suggestion.PermissionIDs)
+		for _, permissionID := range suggestion.PermissionIDs {
+			suggestion.PermissionCodes = append(suggestion.PermissionCodes, permissionCodes[permissionID])
+		}
+		sort.Strings(suggestion.PermissionCodes)
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].CoveredAssignments != suggestions[j].CoveredAssignments {
+			return suggestions[i].CoveredAssignments > suggestions[j].CoveredAssignments
+		}
+		return suggestions[i].Fingerprint < suggestions[j].Fingerprint
+	})
+	if params.MaxSuggestions > 0 && len(suggestions) > params.MaxSuggestions {
+		suggestions = suggestions[:params.MaxSuggestions]
+	}
+	return suggestions
+}
+
+// permissionSetKey calcula uma chave estável para um conjunto de permissões
+func permissionSetKey(permissions map[uuid.UUID]bool) string {
+	ids := make([]string, 0, len(permissions))
+	for permissionID := range permissions {
+		ids = append(ids, permissionID.String())
+	}
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// jaccard calcula a semelhança entre dois conjuntos de permissões
+func jaccard(a, b map[uuid.UUID]bool) float64 {
+	shared := intersectionSize(a, b)
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// intersectionSize conta as permissões presentes em ambos os conjuntos
+func intersectionSize(a, b map[uuid.UUID]bool) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for permissionID := range a {
+		if b[permissionID] {
+			shared++
+		}
+	}
+	return shared
+}
+
+// sortedUUIDs ordena os identificadores para uma saída determinística
+func sortedUUIDs(ids []uuid.UUID) []uuid.UUID {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
@@ -0,0 +1,255 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Biblioteca de modelos de função partilhada entre tenants.
+ * Os operadores da plataforma publicam modelos curados (por exemplo "Analista Financeiro"
+ * ou "Responsável de Compliance" por mercado) que os tenants instanciam como funções próprias;
+ * a instância guarda a versão e o mapeamento de permissões para deteção de desvios.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Chaves de metadados gravadas nas funções instanciadas a partir de um modelo
+const (
+	RoleMetadataTemplateID      = "template_id"
+	RoleMetadataTemplateCode    = "template_code"
+	RoleMetadataTemplateVersion = "template_version"
+)
+
+// Erros dos modelos de função
+var (
+	ErrRoleTemplateNotFound            = errors.New("modelo de função não encontrado")
+	ErrInvalidRoleTemplate             = errors.New("modelo de função inválido")
+	ErrRoleTemplateCodeAlreadyExists   = errors.New("já existe um modelo de função com o mesmo código no mercado")
+	ErrRoleTemplateInactive            = errors.New("modelo de função inativo")
+	ErrRoleTemplateNotAvailable        = errors.New("modelo de função não disponível no mercado do tenant")
+	ErrRoleTemplateConflict            = errors.New("modelo de função alterado concorrentemente")
+	ErrRoleTemplateInstanceNotFound    = errors.New("a função não foi instanciada a partir de um modelo")
+	ErrRoleTemplateAlreadyInstantiated = errors.New("o modelo de função já foi instanciado no tenant")
+	ErrRoleTemplatePermissionsUnmapped = errors.New("permissões do modelo sem correspondência no tenant")
+)
+
+// RoleTemplate representa um modelo de função do catálogo global
+// Um mercado vazio torna o modelo disponível para todos os mercados
+type RoleTemplate struct {
+	ID              uuid.UUID              `json:"id"`
+	Code            string                 `json:"code"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	Market          string                 `json:"market,omitempty"`
+	Version         int                    `json:"version"`
+	PermissionCodes []string               `json:"permission_codes"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+	IsActive        bool                   `json:"is_active"`
+	CreatedBy       uuid.UUID              `json:"created_by"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+// NewRoleTemplate cria um modelo de função ativo na versão 1
+func NewRoleTemplate(code, name, description, market string, permissionCodes []string, createdBy uuid.UUID, now time.Time) (*RoleTemplate, error) {
+	template := &RoleTemplate{
+		ID:              uuid.New(),
+		Code:            strings.TrimSpace(code),
+		Name:            strings.TrimSpace(name),
+		Description:     strings.TrimSpace(description),
+		Market:          strings.ToLower(strings.TrimSpace(market)),
+		Version:         1,
+		PermissionCodes: NormalizePermissionCodes(permissionCodes),
+		IsActive:        true,
+		CreatedBy:       createdBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Validate verifica os dados do modelo
+func (t *RoleTemplate) Validate() error {
+	if t.Code == "" || t.Name == "" {
+		return fmt.Errorf("%w: código e nome são obrigatórios", ErrInvalidRoleTemplate)
+	}
+	if len(t.PermissionCodes) == 0 {
+		return fmt.Errorf("%w: o modelo deve conter pelo menos uma permissão", ErrInvalidRoleTemplate)
+	}
+	return nil
+}
+
+// Revise altera o nome, a descrição e as permissões do modelo
+// A versão só avança quando o conjunto de permissões muda; retorna se avançou
+func (t *RoleTemplate) Revise(name, description string, permissionCodes []string, now time.Time) (bool, error) {
+	revised := *t
+	if name = strings.TrimSpace(name); name != "" {
+		revised.Name = name
+	}
+	revised.Description = strings.TrimSpace(description)
+	if permissionCodes != nil {
+		revised.PermissionCodes = NormalizePermissionCodes(permissionCodes)
+	}
+	if err := revised.Validate(); err != nil {
+		return false, err
+	}
+
+	bumped := !equalCodes(t.PermissionCodes, revised.PermissionCodes)
+	if bumped {
+		revised.Version++
+	}
+	revised.UpdatedAt = now
+	*t = revised
+	return bumped, nil
+}
+
+// AvailableIn indica se o modelo pode ser instanciado no mercado
+func (t *RoleTemplate) AvailableIn(market string) bool {
+	return t.Market == "" || strings.EqualFold(t.Market, strings.TrimSpace(market))
+}
+
+// RoleTemplateFilter define os critérios de listagem do catálogo
+type RoleTemplateFilter struct {
+	// Market restringe aos modelos do mercado e aos disponíveis para todos os mercados
+	Market     string
+	ActiveOnly bool
+}
+
+// RoleTemplateInstance liga uma função de um tenant ao modelo que lhe deu origem
+type RoleTemplateInstance struct {
+	ID              uuid.UUID `json:"id"`
+	TemplateID      uuid.UUID `json:"template_id"`
+	TemplateVersion int       `json:"template_version"`
+	TenantID        uuid.UUID `json:"tenant_id"`
+	RoleID          uuid.UUID `json:"role_id"`
+	// PermissionMapping associa os códigos do modelo aos códigos das permissões do tenant
+	// que diferem; os códigos ausentes correspondem a permissões com o mesmo código
+	PermissionMapping map[string]string `json:"permission_mapping,omitempty"`
+	// UnmappedCodes lista os códigos do modelo sem permissão correspondente no tenant
+	UnmappedCodes  []string  `json:"unmapped_codes,omitempty"`
+	InstantiatedBy uuid.UUID `json:"instantiated_by"`
+	InstantiatedAt time.Time `json:"instantiated_at"`
+}
+
+// TenantCode retorna o código da permissão do tenant correspondente a um código do modelo
+func (i *RoleTemplateInstance) TenantCode(templateCode string) string {
+	if code, ok := i.PermissionMapping[templateCode]; ok && code != "" {
+		return code
+	}
+	return templateCode
+}
+
+// RoleTemplateDrift descreve as diferenças entre uma função instanciada e o seu modelo
+type RoleTemplateDrift struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	RoleID          uuid.UUID `json:"role_id"`
+	TemplateID      uuid.UUID `json:"template_id"`
+	TemplateCode    string    `json:"template_code"`
+	TemplateVersion int       `json:"template_version"`
+	InstanceVersion int       `json:"instance_version"`
+	// Outdated indica que o modelo foi revisto depois da instanciação
+	Outdated bool `json:"outdated"`
+	// MissingPermissions lista as permissões do modelo (códigos do tenant) que a função não tem
+	MissingPermissions []string `json:"missing_permissions"`
+	// ExtraPermissions lista as permissões da função que o modelo não prevê
+	ExtraPermissions []string `json:"extra_permissions"`
+	// UnmappedPermissions lista os códigos do modelo sem correspondência no tenant na instanciação
+	UnmappedPermissions []string  `json:"unmapped_permissions,omitempty"`
+	HasDrift            bool      `json:"has_drift"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// ComputeRoleTemplateDrift compara as permissões atuais da função com as do modelo,
+// traduzidas para os códigos do tenant através do mapeamento da instância.
+// Os códigos que não tinham correspondência na instanciação não contam como desvio.
+func ComputeRoleTemplateDrift(template *RoleTemplate, instance *RoleTemplateInstance, roleCodes []string, now time.Time) *RoleTemplateDrift {
+	drift := &RoleTemplateDrift{
+		TenantID:            instance.TenantID,
+		RoleID:              instance.RoleID,
+		TemplateID:          template.ID,
+		TemplateCode:        template.Code,
+		TemplateVersion:     template.Version,
+		InstanceVersion:     instance.TemplateVersion,
+		Outdated:            instance.TemplateVersion < template.Version,
+		MissingPermissions:  []string{},
+		ExtraPermissions:    []string{},
+		UnmappedPermissions: []string{},
+		CheckedAt:           now,
+	}
+
+	unmapped := make(map[string]bool, len(instance.UnmappedCodes))
+	for _, code := range instance.UnmappedCodes {
+		unmapped[code] = true
+	}
+
+	current := make(map[string]bool, len(roleCodes))
+	for _, code := range roleCodes {
+		current[code] = true
+	}
+
+	expected := make(map[string]bool, len(template.PermissionCodes))
+	for _, templateCode := range template.PermissionCodes {
+		tenantCode := instance.TenantCode(templateCode)
+		expected[tenantCode] = true
+		if current[tenantCode] {
+			continue
+		}
+		if unmapped[templateCode] {
+			drift.UnmappedPermissions = append(drift.UnmappedPermissions, templateCode)
+			continue
+		}
+		drift.MissingPermissions = append(drift.MissingPermissions, tenantCode)
+	}
+
+	for code := range current {
+		if !expected[code] {
+			drift.ExtraPermissions = append(drift.ExtraPermissions, code)
+		}
+	}
+
+	sort.Strings(drift.MissingPermissions)
+	sort.Strings(drift.ExtraPermissions)
+	sort.Strings(drift.UnmappedPermissions)
+	drift.HasDrift = drift.Outdated || len(drift.MissingPermissions) > 0 || len(drift.ExtraPermissions) > 0
+	return drift
+}
+
+// NormalizePermissionCodes remove espaços, vazios e duplicados e ordena os códigos
+func NormalizePermissionCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// equalCodes compara duas listas de códigos normalizadas
+func equalCodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,248 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Federação com fornecedores de identidade externos via SAML 2.0.
+ * Cada tenant regista os IdP em que confia, as regras que convertem os atributos
+ * das asserções em funções e se os usuários são provisionados no primeiro login.
+ */
+
+package model
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operadores das regras de mapeamento de atributos para funções
+const (
+	SAMLRoleMatchEquals  = "equals"
+	SAMLRoleMatchRegex   = "regex"
+	SAMLRoleMatchPresent = "present"
+)
+
+// Formatos de NameID mais usados pelos IdP
+const (
+	SAMLNameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	SAMLNameIDFormatPersistent  = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+	SAMLNameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+// Chave de metadados gravada nos usuários provisionados por federação
+const UserMetadataSAMLProviderID = "saml_provider_id"
+
+// Erros da federação SAML
+var (
+	ErrSAMLProviderNotFound      = errors.New("fornecedor de identidade SAML não encontrado")
+	ErrInvalidSAMLProvider       = errors.New("fornecedor de identidade SAML inválido")
+	ErrSAMLProviderAlreadyExists = errors.New("o tenant já tem um fornecedor de identidade com o mesmo entity ID")
+	ErrSAMLProviderInactive      = errors.New("fornecedor de identidade SAML inativo")
+	ErrInvalidSAMLMetadata       = errors.New("metadados SAML inválidos")
+	ErrInvalidSAMLResponse       = errors.New("resposta SAML inválida")
+	ErrSAMLRequestNotFound       = errors.New("pedido de autenticação SAML desconhecido, expirado ou já utilizado")
+	ErrInvalidSAMLReturnTo       = errors.New("destino de retorno inválido: apenas caminhos relativos são permitidos")
+	ErrFederatedIdentityNotFound = errors.New("identidade federada não encontrada")
+	ErrSAMLProvisioningDisabled  = errors.New("o provisionamento automático está desativado para o fornecedor de identidade")
+	ErrSAMLAccountConflict       = errors.New("já existe uma conta local com o mesmo email ou nome de usuário")
+	ErrFederatedUserNotAllowed   = errors.New("a conta do usuário federado não permite autenticação")
+)
+
+// SAMLAttributeMapping indica os atributos da asserção que preenchem o perfil do usuário
+// Os campos vazios usam os nomes mais comuns; o email recorre ao NameID quando ausente
+type SAMLAttributeMapping struct {
+	Email       string `json:"email,omitempty"`
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// WithDefaults retorna o mapeamento com os nomes padrão nos campos não configurados
+func (m SAMLAttributeMapping) WithDefaults() SAMLAttributeMapping {
+	if m.Email == "" {
+		m.Email = "email"
+	}
+	if m.FirstName == "" {
+		m.FirstName = "givenName"
+	}
+	if m.LastName == "" {
+		m.LastName = "sn"
+	}
+	if m.DisplayName == "" {
+		m.DisplayName = "displayName"
+	}
+	return m
+}
+
+// SAMLRoleMappingRule atribui uma função aos usuários cuja asserção satisfaz a condição
+type SAMLRoleMappingRule struct {
+	Attribute string    `json:"attribute"`
+	Operator  string    `json:"operator"`
+	Value     string    `json:"value,omitempty"`
+	RoleID    uuid.UUID `json:"role_id"`
+}
+
+// Validate verifica a regra de mapeamento
+func (r SAMLRoleMappingRule) Validate() error {
+	if strings.TrimSpace(r.Attribute) == "" {
+		return fmt.Errorf("%w: regra de mapeamento sem atributo", ErrInvalidSAMLProvider)
+	}
+	if r.RoleID == uuid.Nil {
+		return fmt.Errorf("%w: regra de mapeamento do atributo %s sem função", ErrInvalidSAMLProvider, r.Attribute)
+	}
+	switch r.Operator {
+	case SAMLRoleMatchEquals:
+	case SAMLRoleMatchPresent:
+	case SAMLRoleMatchRegex:
+		if _, err := regexp.Compile(r.Value); err != nil {
+			return fmt.Errorf("%w: expressão regular inválida para o atributo %s: %v", ErrInvalidSAMLProvider, r.Attribute, err)
+		}
+	default:
+		return fmt.Errorf("%w: operador de mapeamento desconhecido: %s", ErrInvalidSAMLProvider, r.Operator)
+	}
+	return nil
+}
+
+// Matches indica se algum valor do atributo satisfaz a regra
+// A comparação por igualdade ignora maiúsculas e minúsculas; a expressão regular é ancorada
+func (r SAMLRoleMappingRule) Matches(attributes map[string][]string) bool {
+	values := attributes[r.Attribute]
+	if r.Operator == SAMLRoleMatchPresent {
+		return len(values) > 0
+	}
+
+	var pattern *regexp.Regexp
+	if r.Operator == SAMLRoleMatchRegex {
+		pattern = regexp.MustCompile("^(?:" + r.Value + ")$")
+	}
+	for _, value := range values {
+		switch r.Operator {
+		case SAMLRoleMatchEquals:
+			if strings.EqualFold(value, r.Value) {
+				return true
+			}
+		case SAMLRoleMatchRegex:
+			if pattern.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SAMLIdentityProvider representa um fornecedor de identidade SAML em que o tenant confia
+type SAMLIdentityProvider struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID uuid.UUID `json:"tenant_id"`
+	Name     string    `json:"name"`
+	EntityID string    `json:"entity_id"`
+	SSOURL   string    `json:"sso_url"`
+	// SigningCertificates contém os certificados X.509 (PEM) que assinam as respostas do IdP
+	SigningCertificates []string              `json:"signing_certificates"`
+	NameIDFormat        string                `json:"name_id_format,omitempty"`
+	AttributeMapping    SAMLAttributeMapping  `json:"attribute_mapping"`
+	RoleMappingRules    []SAMLRoleMappingRule `json:"role_mapping_rules"`
+	// JITProvisioning cria o usuário no primeiro login federado
+	JITProvisioning bool      `json:"jit_provisioning"`
+	IsActive        bool      `json:"is_active"`
+	CreatedBy       uuid.UUID `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Validate verifica os dados do fornecedor de identidade
+func (p *SAMLIdentityProvider) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.EntityID) == "" {
+		return fmt.Errorf("%w: nome e entity ID são obrigatórios", ErrInvalidSAMLProvider)
+	}
+	if ssoURL, err := url.Parse(p.SSOURL); err != nil || ssoURL.Host == "" || (ssoURL.Scheme != "https" && ssoURL.Scheme != "http") {
+		return fmt.Errorf("%w: URL de SSO inválido: %q", ErrInvalidSAMLProvider, p.SSOURL)
+	}
+	if len(p.SigningCertificates) == 0 {
+		return fmt.Errorf("%w: é necessário pelo menos um certificado de assinatura", ErrInvalidSAMLProvider)
+	}
+	for i, certificate := range p.SigningCertificates {
+		block, _ := pem.Decode([]byte(certificate))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%w: o certificado %d não está em formato PEM", ErrInvalidSAMLProvider, i+1)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%w: certificado %d inválido: %v", ErrInvalidSAMLProvider, i+1, err)
+		}
+	}
+	for _, rule := range p.RoleMappingRules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MapRoles retorna as funções das regras satisfeitas pelos atributos, sem repetições
+func (p *SAMLIdentityProvider) MapRoles(attributes map[string][]string) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	roles := []uuid.UUID{}
+	for _, rule := range p.RoleMappingRules {
+		if seen[rule.RoleID] || !rule.Matches(attributes) {
+			continue
+		}
+		seen[rule.RoleID] = true
+		roles = append(roles, rule.RoleID)
+	}
+	return roles
+}
+
+// SAMLAuthnRequest regista um pedido de autenticação enviado ao IdP
+// A resposta só é aceite se referir um pedido emitido, não expirado e ainda não consumido
+type SAMLAuthnRequest struct {
+	ID         string     `json:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id"`
+	ProviderID uuid.UUID  `json:"provider_id"`
+	RelayState string     `json:"relay_state"`
+	ReturnTo   string     `json:"return_to,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+}
+
+// SAMLAssertion contém os dados de uma asserção cuja assinatura e condições foram validadas
+type SAMLAssertion struct {
+	NameID       string              `json:"name_id"`
+	NameIDFormat string              `json:"name_id_format,omitempty"`
+	SessionIndex string              `json:"session_index,omitempty"`
+	Attributes   map[string][]string `json:"attributes"`
+}
+
+// Attribute retorna o primeiro valor não vazio do atributo
+func (a *SAMLAssertion) Attribute(name string) string {
+	for _, value := range a.Attributes[name] {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// FederatedIdentity liga o NameID emitido por um IdP a um usuário local
+type FederatedIdentity struct {
+	ID         uuid.UUID `json:"id"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	ProviderID uuid.UUID `json:"provider_id"`
+	Subject    string    `json:"subject"`
+	UserID     uuid.UUID `json:"user_id"`
+	// GrantedRoleIDs lista as funções atribuídas pelas regras de mapeamento; apenas estas
+	// são retiradas quando deixam de corresponder aos atributos da asserção
+	GrantedRoleIDs []uuid.UUID `json:"granted_role_ids"`
+	CreatedAt      time.Time   `json:"created_at"`
+	LastLoginAt    *time.Time  `json:"last_login_at,omitempty"`
+}
@@ -0,0 +1,208 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Incidentes de segurança detetados na análise do fluxo de auditoria.
+ * Cada incidente regista a regra que o originou, a severidade, as evidências recolhidas
+ * e o ciclo de triagem pela equipa de segurança do tenant.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SecurityIncidentSeverity representa a severidade de um incidente de segurança
+type SecurityIncidentSeverity string
+
+// Severidades de um incidente de segurança, por ordem crescente
+const (
+	SecurityIncidentSeverityLow      SecurityIncidentSeverity = "low"
+	SecurityIncidentSeverityMedium   SecurityIncidentSeverity = "medium"
+	SecurityIncidentSeverityHigh     SecurityIncidentSeverity = "high"
+	SecurityIncidentSeverityCritical SecurityIncidentSeverity = "critical"
+)
+
+// IsValid indica se a severidade é conhecida
+func (s SecurityIncidentSeverity) IsValid() bool {
+	switch s {
+	case SecurityIncidentSeverityLow, SecurityIncidentSeverityMedium,
+		SecurityIncidentSeverityHigh, SecurityIncidentSeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+// SecurityIncidentStatus representa o estado de triagem de um incidente
+type SecurityIncidentStatus string
+
+// Estados de triagem de um incidente
+const (
+	SecurityIncidentStatusOpen         SecurityIncidentStatus = "open"
+	SecurityIncidentStatusAcknowledged SecurityIncidentStatus = "acknowledged"
+	SecurityIncidentStatusResolved     SecurityIncidentStatus = "resolved"
+	SecurityIncidentStatusDismissed    SecurityIncidentStatus = "dismissed"
+)
+
+// IsValid indica se o estado é conhecido
+func (s SecurityIncidentStatus) IsValid() bool {
+	switch s {
+	case SecurityIncidentStatusOpen, SecurityIncidentStatusAcknowledged,
+		SecurityIncidentStatusResolved, SecurityIncidentStatusDismissed:
+		return true
+	default:
+		return false
+	}
+}
+
+// SecurityIncidentRule identifica a regra de deteção que originou o incidente
+type SecurityIncidentRule string
+
+// Regras de deteção de anomalias no fluxo de auditoria e dos controlos de acesso
+const (
+	// Concessão de função ou permissão fora do horário de expediente do tenant
+	SecurityIncidentRuleOffHoursPrivilegeGrant SecurityIncidentRule = "off_hours_privilege_grant"
+	// Exclusão de várias funções pelo mesmo ator num curto intervalo
+	SecurityIncidentRuleMassRoleDeletion SecurityIncidentRule = "mass_role_deletion"
+	// Concessão de permissões por um administrador promovido recentemente
+	SecurityIncidentRuleNewAdminPermissionGrant SecurityIncidentRule = "new_admin_permission_grant"
+	// Tentativa de ultrapassar um limite de permissões, na atribuição ou na avaliação do PDP
+	SecurityIncidentRulePermissionBoundaryViolation SecurityIncidentRule = "permission_boundary_violation"
+	// Mesmo dispositivo usado por várias contas do tenant, sinal de fraude ou de conta partilhada
+	SecurityIncidentRuleDeviceFingerprintReuse SecurityIncidentRule = "device_fingerprint_reuse"
+)
+
+// Erros dos incidentes de segurança
+var (
+	ErrSecurityIncidentNotFound          = errors.New("incidente de segurança não encontrado")
+	ErrInvalidSecurityIncident           = errors.New("incidente de segurança inválido")
+	ErrInvalidSecurityIncidentTransition = errors.New("transição de estado inválida para o incidente de segurança")
+	ErrSecurityIncidentConflict          = errors.New("incidente de segurança alterado concorrentemente")
+)
+
+// SecurityIncidentEvidence descreve um evento de auditoria que contribuiu para o incidente
+type SecurityIncidentEvidence struct {
+	EventType  string     `json:"event_type"`
+	OccurredAt time.Time  `json:"occurred_at"`
+	RoleID     *uuid.UUID `json:"role_id,omitempty"`
+	RoleCode   string     `json:"role_code,omitempty"`
+	// Usuários ou permissões afetados pelo evento
+	SubjectIDs []uuid.UUID `json:"subject_ids,omitempty"`
+}
+
+// SecurityIncident representa uma anomalia detetada no fluxo de auditoria de um tenant
+type SecurityIncident struct {
+	ID             uuid.UUID                  `json:"id"`
+	TenantID       uuid.UUID                  `json:"tenant_id"`
+	Rule           SecurityIncidentRule       `json:"rule"`
+	Severity       SecurityIncidentSeverity   `json:"severity"`
+	Status         SecurityIncidentStatus     `json:"status"`
+	ActorID        *uuid.UUID                 `json:"actor_id,omitempty"`
+	Summary        string                     `json:"summary"`
+	Evidence       []SecurityIncidentEvidence `json:"evidence"`
+	DetectedAt     time.Time                  `json:"detected_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	TriagedBy      *uuid.UUID                 `json:"triaged_by,omitempty"`
+	ResolutionNote string                     `json:"resolution_note,omitempty"`
+	ClosedAt       *time.Time                 `json:"closed_at,omitempty"`
+}
+
+// NewSecurityIncident cria um incidente aberto
+func NewSecurityIncident(
+	tenantID uuid.UUID,
+	rule SecurityIncidentRule,
+	severity SecurityIncidentSeverity,
+	actorID *uuid.UUID,
+	summary string,
+	evidence []SecurityIncidentEvidence,
+	now time.Time,
+) (*SecurityIncident, error) {
+	if tenantID == uuid.Nil {
+		return nil, fmt.Errorf("%w: tenant obrigatório", ErrInvalidSecurityIncident)
+	}
+	if !severity.IsValid() {
+		return nil, fmt.Errorf("%w: severidade desconhecida %q", ErrInvalidSecurityIncident, severity)
+	}
+	if len(evidence) == 0 {
+		return nil, fmt.Errorf("%w: evidências obrigatórias", ErrInvalidSecurityIncident)
+	}
+
+	return &SecurityIncident{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		Rule:       rule,
+		Severity:   severity,
+		Status:     SecurityIncidentStatusOpen,
+		ActorID:    actorID,
+		Summary:    summary,
+		Evidence:   evidence,
+		DetectedAt: now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+// IsClosed indica se o incidente já foi resolvido ou descartado
+func (i *SecurityIncident) IsClosed() bool {
+	return i.Status == SecurityIncidentStatusResolved || i.Status == SecurityIncidentStatusDismissed
+}
+
+// Acknowledge assinala que o incidente está a ser analisado
+func (i *SecurityIncident) Acknowledge(actorID uuid.UUID, note string, now time.Time) error {
+	if i.Status != SecurityIncidentStatusOpen {
+		return fmt.Errorf("%w: estado atual %s", ErrInvalidSecurityIncidentTransition, i.Status)
+	}
+	i.triage(SecurityIncidentStatusAcknowledged, actorID, note, now)
+	return nil
+}
+
+// Resolve fecha o incidente após a correção. A nota é obrigatória para a auditoria
+func (i *SecurityIncident) Resolve(actorID uuid.UUID, note string, now time.Time) error {
+	return i.close(SecurityIncidentStatusResolved, actorID, note, now)
+}
+
+// Dismiss fecha o incidente como falso positivo. A nota é obrigatória para a auditoria
+func (i *SecurityIncident) Dismiss(actorID uuid.UUID, note string, now time.Time) error {
+	return i.close(SecurityIncidentStatusDismissed, actorID, note, now)
+}
+
+// close fecha um incidente aberto ou em análise
+func (i *SecurityIncident) close(status SecurityIncidentStatus, actorID uuid.UUID, note string, now time.Time) error {
+	if i.IsClosed() {
+		return fmt.Errorf("%w: estado atual %s", ErrInvalidSecurityIncidentTransition, i.Status)
+	}
+	if strings.TrimSpace(note) == "" {
+		return fmt.Errorf("%w: nota obrigatória para fechar o incidente", ErrInvalidSecurityIncident)
+	}
+
+	i.triage(status, actorID, note, now)
+	closedAt := now
+	i.ClosedAt = &closedAt
+	return nil
+}
+
+// triage regista a transição de estado e o responsável pela triagem
+func (i *SecurityIncident) triage(status SecurityIncidentStatus, actorID uuid.UUID, note string, now time.Time) {
+	triagedBy := actorID
+	i.Status = status
+	i.TriagedBy = &triagedBy
+	if note = strings.TrimSpace(note); note != "" {
+		i.ResolutionNote = note
+	}
+	i.UpdatedAt = now
+}
+
+// SecurityIncidentFilter restringe a listagem de incidentes de segurança
+type SecurityIncidentFilter struct {
+	Status   SecurityIncidentStatus
+	Severity SecurityIncidentSeverity
+	Rule     SecurityIncidentRule
+	Since    *time.Time
+	Limit    int
+}
@@ -0,0 +1,321 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Contas de serviço: identidades dos workloads do tenant, distintas dos usuários. Cada conta
+ * tem funções atribuídas e autentica-se com chaves de API, certificados mTLS, credenciais de
+ * cliente OIDC ou, quando ligada a uma conta de serviço Kubernetes, com os tokens dessa conta
+ * validados por TokenReview. As autenticações são contabilizadas por dia e método para
+ * identificar as contas que deixaram de ser usadas.
+ */
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limites das contas de serviço
+const (
+	MaxServiceAccountCredentials = 10
+	MaxServiceAccountRoles       = 50
+)
+
+// dnsLabelPattern valida os rótulos DNS (RFC 1123): os nomes das contas, que servem de client_id,
+// e os clusters e namespaces Kubernetes
+var dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// kubernetesServiceAccountPattern valida os nomes das contas de serviço Kubernetes (subdomínios DNS)
+var kubernetesServiceAccountPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,251}[a-z0-9])?$`)
+
+// kubernetesServiceAccountUserPrefix antecede o namespace e o nome nos usuários autenticados por TokenReview
+const kubernetesServiceAccountUserPrefix = "system:serviceaccount:"
+
+// ServiceAccountStatus representa o estado de uma conta de serviço
+type ServiceAccountStatus string
+
+// Estados das contas de serviço
+const (
+	ServiceAccountActive   ServiceAccountStatus = "active"
+	ServiceAccountDisabled ServiceAccountStatus = "disabled"
+)
+
+// IsValid indica se o estado é conhecido
+func (s ServiceAccountStatus) IsValid() bool {
+	return s == ServiceAccountActive || s == ServiceAccountDisabled
+}
+
+// ServiceAccountCredentialType representa o tipo de uma credencial de conta de serviço
+type ServiceAccountCredentialType string
+
+// Tipos de credenciais
+const (
+	CredentialTypeAPIKey     ServiceAccountCredentialType = "api_key"
+	CredentialTypeMTLSCert   ServiceAccountCredentialType = "mtls_cert"
+	CredentialTypeOIDCClient ServiceAccountCredentialType = "oidc_client"
+)
+
+// IsValid indica se o tipo de credencial é conhecido
+func (t ServiceAccountCredentialType) IsValid() bool {
+	return t == CredentialTypeAPIKey || t == CredentialTypeMTLSCert || t == CredentialTypeOIDCClient
+}
+
+// ServiceAccountAuthMethod representa o método com que uma conta de serviço se autenticou:
+// um dos tipos de credencial ou um token de conta de serviço Kubernetes
+type ServiceAccountAuthMethod string
+
+// Métodos de autenticação
+const (
+	AuthMethodAPIKey     ServiceAccountAuthMethod = ServiceAccountAuthMethod(CredentialTypeAPIKey)
+	AuthMethodMTLSCert   ServiceAccountAuthMethod = ServiceAccountAuthMethod(CredentialTypeMTLSCert)
+	AuthMethodOIDCClient ServiceAccountAuthMethod = ServiceAccountAuthMethod(CredentialTypeOIDCClient)
+	AuthMethodKubernetes ServiceAccountAuthMethod = "kubernetes"
+)
+
+// WorkloadIdentityBinding liga uma conta de serviço a uma conta de serviço Kubernetes
+type WorkloadIdentityBinding struct {
+	Cluster        string `json:"cluster"`
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"service_account"`
+}
+
+// Normalize remove os espaços dos campos da ligação
+func (b *WorkloadIdentityBinding) Normalize() {
+	b.Cluster = strings.TrimSpace(b.Cluster)
+	b.Namespace = strings.TrimSpace(b.Namespace)
+	b.ServiceAccount = strings.TrimSpace(b.ServiceAccount)
+}
+
+// Validate valida a ligação
+func (b *WorkloadIdentityBinding) Validate() error {
+	if !dnsLabelPattern.MatchString(b.Cluster) {
+		return fmt.Errorf("%w: cluster inválido %q", ErrInvalidWorkloadIdentity, b.Cluster)
+	}
+	if !dnsLabelPattern.MatchString(b.Namespace) {
+		return fmt.Errorf("%w: namespace inválido %q", ErrInvalidWorkloadIdentity, b.Namespace)
+	}
+	if !kubernetesServiceAccountPattern.MatchString(b.ServiceAccount) {
+		return fmt.Errorf("%w: conta de serviço inválida %q", ErrInvalidWorkloadIdentity, b.ServiceAccount)
+	}
+	return nil
+}
+
+// Username retorna o usuário com que o Kubernetes autentica os tokens da conta de serviço
+func (b *WorkloadIdentityBinding) Username() string {
+	return kubernetesServiceAccountUserPrefix + b.Namespace + ":" + b.ServiceAccount
+}
+
+// ParseKubernetesServiceAccountUser interpreta o usuário de um TokenReview
+// (system:serviceaccount:<namespace>:<nome>) e retorna a ligação correspondente no cluster
+func ParseKubernetesServiceAccountUser(cluster, username string) (*WorkloadIdentityBinding, error) {
+	parts := strings.Split(strings.TrimPrefix(username, kubernetesServiceAccountUserPrefix), ":")
+	if !strings.HasPrefix(username, kubernetesServiceAccountUserPrefix) || len(parts) != 2 {
+		return nil, fmt.Errorf("%w: %q não é uma conta de serviço", ErrInvalidWorkloadIdentity, username)
+	}
+	binding := &WorkloadIdentityBinding{Cluster: cluster, Namespace: parts[0], ServiceAccount: parts[1]}
+	if err := binding.Validate(); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// ServiceAccount é a identidade de um workload do tenant
+// As funções atribuídas são geridas à parte e lidas em RoleIDs
+type ServiceAccount struct {
+	ID               uuid.UUID                `json:"id"`
+	TenantID         uuid.UUID                `json:"tenant_id"`
+	Name             string                   `json:"name"`
+	Description      string                   `json:"description,omitempty"`
+	Status           ServiceAccountStatus     `json:"status"`
+	RoleIDs          []uuid.UUID              `json:"role_ids"`
+	WorkloadIdentity *WorkloadIdentityBinding `json:"workload_identity,omitempty"`
+	LastUsedAt       *time.Time               `json:"last_used_at,omitempty"`
+	CreatedBy        uuid.UUID                `json:"created_by"`
+	UpdatedBy        uuid.UUID                `json:"updated_by"`
+	CreatedAt        time.Time                `json:"created_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+// Normalize remove espaços, passa o nome a minúsculas e aplica o estado padrão
+func (a *ServiceAccount) Normalize() {
+	a.Name = strings.ToLower(strings.TrimSpace(a.Name))
+	a.Description = strings.TrimSpace(a.Description)
+	if a.Status == "" {
+		a.Status = ServiceAccountActive
+	}
+	if a.WorkloadIdentity != nil {
+		a.WorkloadIdentity.Normalize()
+	}
+}
+
+// Validate valida a conta de serviço
+func (a *ServiceAccount) Validate() error {
+	if a.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !dnsLabelPattern.MatchString(a.Name) {
+		return fmt.Errorf("%w: nome inválido %q (letras minúsculas, dígitos e hífens, até 63 caracteres)",
+			ErrInvalidServiceAccount, a.Name)
+	}
+	if !a.Status.IsValid() {
+		return fmt.Errorf("%w: estado %q desconhecido", ErrInvalidServiceAccount, a.Status)
+	}
+	if a.WorkloadIdentity != nil {
+		return a.WorkloadIdentity.Validate()
+	}
+	return nil
+}
+
+// IsActive indica se a conta pode autenticar-se
+func (a *ServiceAccount) IsActive() bool {
+	return a.Status == ServiceAccountActive
+}
+
+// IsDormant indica se uma conta ativa não se autentica há pelo menos dormantAfter; as contas
+// que nunca foram usadas contam desde a sua criação
+func (a *ServiceAccount) IsDormant(now time.Time, dormantAfter time.Duration) bool {
+	if !a.IsActive() {
+		return false
+	}
+	reference := a.CreatedAt
+	if a.LastUsedAt != nil {
+		reference = *a.LastUsedAt
+	}
+	return !reference.After(now.Add(-dormantAfter))
+}
+
+// ServiceAccountFilter filtra as contas de serviço do tenant
+type ServiceAccountFilter struct {
+	Status ServiceAccountStatus
+}
+
+// ServiceAccountCredential é uma credencial de uma conta de serviço
+// Só os campos do tipo da credencial são preenchidos; os segredos são guardados apenas como hash
+type ServiceAccountCredential struct {
+	ID                     uuid.UUID                    `json:"id"`
+	TenantID               uuid.UUID                    `json:"tenant_id"`
+	ServiceAccountID       uuid.UUID                    `json:"service_account_id"`
+	Type                   ServiceAccountCredentialType `json:"type"`
+	Name                   string                       `json:"name,omitempty"`
+	KeyPrefix              string                       `json:"key_prefix,omitempty"`
+	ClientID               string                       `json:"client_id,omitempty"`
+	SecretHash             []byte                       `json:"-"`
+	CertificateFingerprint string                       `json:"certificate_fingerprint,omitempty"`
+	CertificateSubject     string                       `json:"certificate_subject,omitempty"`
+	ExpiresAt              *time.Time                   `json:"expires_at,omitempty"`
+	LastUsedAt             *time.Time                   `json:"last_used_at,omitempty"`
+	CreatedBy              uuid.UUID                    `json:"created_by"`
+	CreatedAt              time.Time                    `json:"created_at"`
+	RevokedBy              *uuid.UUID                   `json:"revoked_by,omitempty"`
+	RevokedAt              *time.Time                   `json:"revoked_at,omitempty"`
+}
+
+// Validate valida a credencial e os campos exigidos pelo seu tipo
+func (c *ServiceAccountCredential) Validate() error {
+	if c.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if len(c.Name) > 100 {
+		return fmt.Errorf("%w: o nome tem no máximo 100 caracteres", ErrInvalidServiceAccountCredential)
+	}
+	if c.ExpiresAt != nil && !c.ExpiresAt.After(c.CreatedAt) {
+		return fmt.Errorf("%w: a validade tem de ser posterior à emissão", ErrInvalidServiceAccountCredential)
+	}
+
+	switch c.Type {
+	case CredentialTypeAPIKey:
+		if c.KeyPrefix == "" || len(c.SecretHash) == 0 {
+			return fmt.Errorf("%w: a chave de API exige prefixo e segredo", ErrInvalidServiceAccountCredential)
+		}
+	case CredentialTypeOIDCClient:
+		if c.ClientID == "" || len(c.SecretHash) == 0 {
+			return fmt.Errorf("%w: o cliente OIDC exige client_id e segredo", ErrInvalidServiceAccountCredential)
+		}
+	case CredentialTypeMTLSCert:
+		if len(c.CertificateFingerprint) != sha256.Size*2 {
+			return fmt.Errorf("%w: o certificado mTLS exige a impressão digital SHA-256", ErrInvalidServiceAccountCredential)
+		}
+	default:
+		return fmt.Errorf("%w: tipo %q desconhecido", ErrInvalidServiceAccountCredential, c.Type)
+	}
+	return nil
+}
+
+// IsActive indica se a credencial não foi revogada nem expirou
+func (c *ServiceAccountCredential) IsActive(now time.Time) bool {
+	return c.RevokedAt == nil && (c.ExpiresAt == nil || now.Before(*c.ExpiresAt))
+}
+
+// CertificateFingerprint retorna a impressão digital SHA-256 de um certificado em DER, em hexadecimal
+func CertificateFingerprint(der []byte) string {
+	digest := sha256.Sum256(der)
+	return hex.EncodeToString(digest[:])
+}
+
+// ServiceAccountUsage é uma autenticação bem-sucedida de uma conta de serviço
+// CredentialID fica vazio nas autenticações por token Kubernetes
+type ServiceAccountUsage struct {
+	TenantID         uuid.UUID
+	ServiceAccountID uuid.UUID
+	CredentialID     *uuid.UUID
+	Method           ServiceAccountAuthMethod
+	UsedAt           time.Time
+}
+
+// ServiceAccountDailyUsage é o número de autenticações de uma conta num dia, por método
+type ServiceAccountDailyUsage struct {
+	ServiceAccountID uuid.UUID                `json:"service_account_id"`
+	Day              time.Time                `json:"day"`
+	Method           ServiceAccountAuthMethod `json:"method"`
+	Authentications  int                      `json:"authentications"`
+}
+
+// ServiceAccountUsageSummary resume a utilização de uma conta de serviço no período do relatório
+type ServiceAccountUsageSummary struct {
+	ServiceAccountID uuid.UUID                        `json:"service_account_id"`
+	Name             string                           `json:"name"`
+	Status           ServiceAccountStatus             `json:"status"`
+	Authentications  int                              `json:"authentications"`
+	ByMethod         map[ServiceAccountAuthMethod]int `json:"by_method"`
+	ActiveDays       int                              `json:"active_days"`
+	LastUsedAt       *time.Time                       `json:"last_used_at,omitempty"`
+	// Dias desde a última autenticação ou, se a conta nunca foi usada, desde a sua criação
+	IdleDays int  `json:"idle_days"`
+	Dormant  bool `json:"dormant"`
+}
+
+// ServiceAccountUsageReport é o relatório de utilização das contas de serviço do tenant
+type ServiceAccountUsageReport struct {
+	Since            time.Time                     `json:"since"`
+	GeneratedAt      time.Time                     `json:"generated_at"`
+	DormantAfterDays int                           `json:"dormant_after_days"`
+	DormantAccounts  int                           `json:"dormant_accounts"`
+	Accounts         []*ServiceAccountUsageSummary `json:"accounts"`
+}
+
+// Erros específicos das contas de serviço
+var (
+	ErrServiceAccountNotFound              = errors.New("conta de serviço não encontrada")
+	ErrServiceAccountConflict              = errors.New("já existe uma conta de serviço com este nome no tenant")
+	ErrInvalidServiceAccount               = errors.New("conta de serviço inválida")
+	ErrServiceAccountRoleNotFound          = errors.New("função não encontrada no tenant")
+	ErrServiceAccountRoleNotBound          = errors.New("a função não está atribuída à conta de serviço")
+	ErrServiceAccountRoleLimit             = errors.New("a conta de serviço atingiu o número máximo de funções")
+	ErrInvalidWorkloadIdentity             = errors.New("ligação à conta de serviço Kubernetes inválida")
+	ErrWorkloadIdentityConflict            = errors.New("a conta de serviço Kubernetes já está ligada a outra conta de serviço")
+	ErrServiceAccountCredentialNotFound    = errors.New("credencial da conta de serviço não encontrada")
+	ErrServiceAccountCredentialConflict    = errors.New("a credencial já está registada")
+	ErrInvalidServiceAccountCredential     = errors.New("credencial da conta de serviço inválida")
+	ErrServiceAccountCredentialLimit       = errors.New("a conta de serviço atingiu o número máximo de credenciais ativas")
+	ErrServiceAccountAuthenticationFailed  = errors.New("autenticação da conta de serviço falhou")
+	ErrInvalidServiceAccountAuthentication = errors.New("pedido de autenticação da conta de serviço inválido")
+	ErrInvalidServiceAccountUsageFilter    = errors.New("filtro do relatório de utilização inválido")
+)
@@ -0,0 +1,252 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Políticas de sessão por tenant: validade dos tokens de acesso e de renovação, duração
+ * máxima da sessão e tempo máximo de inatividade. O mercado do tenant limita os valores
+ * aceites aos tenants do setor financeiro (por exemplo, sessões mais curtas na UE).
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Erros das políticas de sessão
+var (
+	ErrInvalidSessionPolicy       = errors.New("política de sessão inválida")
+	ErrSessionPolicyExceedsMarket = errors.New("a política de sessão excede os limites do mercado do tenant")
+)
+
+// Motivos de recusa de uma sessão
+const (
+	SessionRejectTokenLifetime = "token_lifetime_exceeded"
+	SessionRejectAbsolute      = "session_lifetime_exceeded"
+	SessionRejectIdle          = "session_idle_timeout"
+)
+
+// Limites comuns a todos os tenants
+const (
+	MinSessionTokenTTL    = time.Minute
+	MinSessionIdleTimeout = time.Minute
+)
+
+// SessionLimits define os valores máximos aceites numa política de sessão
+type SessionLimits struct {
+	AccessTokenTTL   time.Duration `json:"access_token_ttl"`
+	RefreshTokenTTL  time.Duration `json:"refresh_token_ttl"`
+	AbsoluteLifetime time.Duration `json:"absolute_lifetime"`
+	IdleTimeout      time.Duration `json:"idle_timeout"`
+}
+
+// defaultSessionLimits limita os tenants que não são do setor financeiro
+var defaultSessionLimits = SessionLimits{
+	AccessTokenTTL:   time.Hour,
+	RefreshTokenTTL:  30 * 24 * time.Hour,
+	AbsoluteLifetime: 30 * 24 * time.Hour,
+	IdleTimeout:      24 * time.Hour,
+}
+
+// financialSessionLimits define os limites de cada mercado para os tenants financeiros
+// Na UE (PSD2, RTS art. 4.º, n.º 3, alínea d) a sessão termina após 5 minutos de inatividade;
+// o BNA e o Banco de Moçambique exigem reautenticação frequente nos serviços financeiros
+var financialSessionLimits = map[string]SessionLimits{
+	"eu":         {AccessTokenTTL: 5 * time.Minute, RefreshTokenTTL: time.Hour, AbsoluteLifetime: 8 * time.Hour, IdleTimeout: 5 * time.Minute},
+	"angola":     {AccessTokenTTL: 10 * time.Minute, RefreshTokenTTL: 8 * time.Hour, AbsoluteLifetime: 12 * time.Hour, IdleTimeout: 10 * time.Minute},
+	"mozambique": {AccessTokenTTL: 10 * time.Minute, RefreshTokenTTL: 8 * time.Hour, AbsoluteLifetime: 12 * time.Hour, IdleTimeout: 10 * time.Minute},
+	"brazil":     {AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 12 * time.Hour, AbsoluteLifetime: 12 * time.Hour, IdleTimeout: 15 * time.Minute},
+	"usa":        {AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 24 * time.Hour, AbsoluteLifetime: 24 * time.Hour, IdleTimeout: 30 * time.Minute},
+}
+
+// defaultSessionPolicy define os valores usados pelos tenants não financeiros sem política própria
+var defaultSessionPolicy = SessionLimits{
+	AccessTokenTTL:   15 * time.Minute,
+	RefreshTokenTTL:  24 * time.Hour,
+	AbsoluteLifetime: 7 * 24 * time.Hour,
+	IdleTimeout:      2 * time.Hour,
+}
+
+// SessionLimitsFor retorna os limites aplicáveis ao tenant
+// Os tenants financeiros de mercados sem limites próprios usam os da UE, os mais restritivos
+func SessionLimitsFor(market string, financialTenant bool) SessionLimits {
+	if !financialTenant {
+		return defaultSessionLimits
+	}
+	if limits, ok := financialSessionLimits[strings.ToLower(strings.TrimSpace(market))]; ok {
+		return limits
+	}
+	return financialSessionLimits["eu"]
+}
+
+// SessionPolicy representa a política de sessão de um tenant
+// Os valores são gravados e expostos em segundos
+type SessionPolicy struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Market   string    `json:"market,omitempty"`
+	// FinancialTenant indica que o tenant presta serviços financeiros e está sujeito aos limites do mercado
+	FinancialTenant         bool `json:"financial_tenant"`
+	AccessTokenTTLSeconds   int  `json:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds  int  `json:"refresh_token_ttl_seconds"`
+	AbsoluteLifetimeSeconds int  `json:"absolute_lifetime_seconds"`
+	IdleTimeoutSeconds      int  `json:"idle_timeout_seconds"`
+	// Default indica que o tenant não configurou a política e são usados os valores padrão do mercado
+	Default   bool      `json:"default"`
+	UpdatedBy uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// DefaultSessionPolicy retorna a política de um tenant que ainda não a configurou
+// Os tenants financeiros usam os limites do mercado
+func DefaultSessionPolicy(tenantID uuid.UUID, market string, financialTenant bool) *SessionPolicy {
+	values := defaultSessionPolicy
+	if financialTenant {
+		values = SessionLimitsFor(market, true)
+	}
+	return &SessionPolicy{
+		TenantID:                tenantID,
+		Market:                  market,
+		FinancialTenant:         financialTenant,
+		AccessTokenTTLSeconds:   int(values.AccessTokenTTL / time.Second),
+		RefreshTokenTTLSeconds:  int(values.RefreshTokenTTL / time.Second),
+		AbsoluteLifetimeSeconds: int(values.AbsoluteLifetime / time.Second),
+		IdleTimeoutSeconds:      int(values.IdleTimeout / time.Second),
+		Default:                 true,
+	}
+}
+
+// AccessTokenTTL retorna a validade dos tokens de acesso
+func (p *SessionPolicy) AccessTokenTTL() time.Duration {
+	return time.Duration(p.AccessTokenTTLSeconds) * time.Second
+}
+
+// RefreshTokenTTL retorna a validade dos tokens de renovação
+func (p *SessionPolicy) RefreshTokenTTL() time.Duration {
+	return time.Duration(p.RefreshTokenTTLSeconds) * time.Second
+}
+
+// AbsoluteLifetime retorna a duração máxima da sessão desde a autenticação
+func (p *SessionPolicy) AbsoluteLifetime() time.Duration {
+	return time.Duration(p.AbsoluteLifetimeSeconds) * time.Second
+}
+
+// IdleTimeout retorna o tempo máximo sem pedidos após o qual a sessão termina
+func (p *SessionPolicy) IdleTimeout() time.Duration {
+	return time.Duration(p.IdleTimeoutSeconds) * time.Second
+}
+
+// Validate verifica a coerência da política e a sua conformidade com os limites do mercado
+func (p *SessionPolicy) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if p.AccessTokenTTL() < MinSessionTokenTTL || p.RefreshTokenTTL() < MinSessionTokenTTL {
+		return fmt.Errorf("%w: a validade dos tokens deve ser de pelo menos %s", ErrInvalidSessionPolicy, MinSessionTokenTTL)
+	}
+	if p.IdleTimeout() < MinSessionIdleTimeout {
+		return fmt.Errorf("%w: o tempo de inatividade deve ser de pelo menos %s", ErrInvalidSessionPolicy, MinSessionIdleTimeout)
+	}
+	if p.AccessTokenTTL() > p.RefreshTokenTTL() {
+		return fmt.Errorf("%w: o token de acesso não pode durar mais que o de renovação", ErrInvalidSessionPolicy)
+	}
+	if p.RefreshTokenTTL() > p.AbsoluteLifetime() || p.IdleTimeout() > p.AbsoluteLifetime() {
+		return fmt.Errorf("%w: a duração máxima da sessão deve cobrir o token de renovação e a inatividade", ErrInvalidSessionPolicy)
+	}
+
+	limits := SessionLimitsFor(p.Market, p.FinancialTenant)
+	switch {
+	case p.AccessTokenTTL() > limits.AccessTokenTTL:
+		return fmt.Errorf("%w: token de acesso limitado a %s", ErrSessionPolicyExceedsMarket, limits.AccessTokenTTL)
+	case p.RefreshTokenTTL() > limits.RefreshTokenTTL:
+		return fmt.Errorf("%w: token de renovação limitado a %s", ErrSessionPolicyExceedsMarket, limits.RefreshTokenTTL)
+	case p.AbsoluteLifetime() > limits.AbsoluteLifetime:
+		return fmt.Errorf("%w: sessão limitada a %s", ErrSessionPolicyExceedsMarket, limits.AbsoluteLifetime)
+	case p.IdleTimeout() > limits.IdleTimeout:
+		return fmt.Errorf("%w: inatividade limitada a %s", ErrSessionPolicyExceedsMarket, limits.IdleTimeout)
+	}
+	return nil
+}
+
+// Lifetimes calcula a validade dos tokens emitidos agora para uma sessão autenticada em authTime
+// Nenhum token pode ultrapassar o fim da sessão
+func (p *SessionPolicy) Lifetimes(authTime, now time.Time) *SessionLifetimes {
+	sessionExpiresAt := authTime.Add(p.AbsoluteLifetime())
+	return &SessionLifetimes{
+		AccessTokenExpiresAt:  minTime(now.Add(p.AccessTokenTTL()), sessionExpiresAt),
+		RefreshTokenExpiresAt: minTime(now.Add(p.RefreshTokenTTL()), sessionExpiresAt),
+		SessionExpiresAt:      sessionExpiresAt,
+		IdleTimeoutSeconds:    p.IdleTimeoutSeconds,
+	}
+}
+
+// Evaluate verifica a validade do token e a duração da sessão; a inatividade depende do
+// registo de atividade e é verificada pelo serviço. A tolerância cobre a diferença entre
+// os relógios do emissor e deste serviço
+func (p *SessionPolicy) Evaluate(check *SessionCheck, now time.Time, skew time.Duration) SessionDecision {
+	decision := SessionDecision{Allowed: true}
+	if !check.AuthTime.IsZero() {
+		decision.SessionExpiresAt = check.AuthTime.Add(p.AbsoluteLifetime())
+		if now.After(decision.SessionExpiresAt.Add(skew)) {
+			return SessionDecision{Reason: SessionRejectAbsolute, SessionExpiresAt: decision.SessionExpiresAt}
+		}
+	}
+
+	// Os tokens emitidos antes de a política ser encurtada deixam de ser aceites
+	if !check.IssuedAt.IsZero() {
+		maxExpiresAt := check.IssuedAt.Add(p.AccessTokenTTL() + skew)
+		if now.After(maxExpiresAt) || (!check.ExpiresAt.IsZero() && check.ExpiresAt.After(maxExpiresAt)) {
+			return SessionDecision{Reason: SessionRejectTokenLifetime, SessionExpiresAt: decision.SessionExpiresAt}
+		}
+	}
+	return decision
+}
+
+// SessionLifetimes representa a validade dos tokens a emitir para uma sessão
+type SessionLifetimes struct {
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	SessionExpiresAt      time.Time `json:"session_expires_at"`
+	IdleTimeoutSeconds    int       `json:"idle_timeout_seconds"`
+}
+
+// SessionCheck representa a verificação de um pedido autenticado
+// SessionID identifica a sessão no registo de atividade; sem ele a inatividade não é verificada
+type SessionCheck struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID string    `json:"session_id,omitempty"`
+	Market    string    `json:"market,omitempty"`
+	IssuedAt  time.Time `json:"issued_at,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	AuthTime  time.Time `json:"auth_time,omitempty"`
+}
+
+// SessionDecision representa o resultado da verificação de uma sessão
+type SessionDecision struct {
+	Allowed          bool      `json:"allowed"`
+	Reason           string    `json:"reason,omitempty"`
+	SessionExpiresAt time.Time `json:"session_expires_at,omitempty"`
+}
+
+// SessionActivity representa o registo de atividade de uma sessão, usado para a inatividade
+type SessionActivity struct {
+	TenantID   uuid.UUID  `json:"tenant_id"`
+	SessionID  string     `json:"session_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+}
+
+// minTime retorna o instante mais cedo
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
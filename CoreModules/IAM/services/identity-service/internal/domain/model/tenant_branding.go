@@ -0,0 +1,612 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Identidade visual e modelos de email de cada tenant: os fluxos de autoatendimento
+ * (convites, redefinição de senha e inscrição de MFA) enviam emails com o nome, o logótipo
+ * e as cores do tenant, a partir de modelos por idioma com versões. Cada versão é publicada
+ * depois de pré-visualizada e a publicação de uma versão anterior repõe-na. O HTML dos
+ * modelos é sanitizado antes de ser compilado e as variáveis são escapadas no envio.
+ */
+
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// EmailTemplateKind identifica o email de um fluxo de autoatendimento
+type EmailTemplateKind string
+
+// Emails dos fluxos de autoatendimento
+const (
+	// EmailTemplateKindInvite convida um usuário a ativar a conta criada para ele
+	EmailTemplateKindInvite EmailTemplateKind = "invite"
+	// EmailTemplateKindPasswordReset envia o link de redefinição da senha
+	EmailTemplateKindPasswordReset EmailTemplateKind = "password_reset"
+	// EmailTemplateKindMFAEnrollment pede ao usuário que configure a autenticação multifator
+	EmailTemplateKindMFAEnrollment EmailTemplateKind = "mfa_enrollment"
+)
+
+// EmailTemplateKinds lista os emails que o tenant pode personalizar
+var EmailTemplateKinds = []EmailTemplateKind{
+	EmailTemplateKindInvite,
+	EmailTemplateKindPasswordReset,
+	EmailTemplateKindMFAEnrollment,
+}
+
+// IsValid indica se o email é conhecido
+func (k EmailTemplateKind) IsValid() bool {
+	switch k {
+	case EmailTemplateKindInvite, EmailTemplateKindPasswordReset, EmailTemplateKindMFAEnrollment:
+		return true
+	}
+	return false
+}
+
+// EmailTemplateStatus é o estado de uma versão de um modelo de email
+type EmailTemplateStatus string
+
+// Estados das versões dos modelos de email
+const (
+	// EmailTemplateDraft é uma versão gravada que ainda não foi publicada
+	EmailTemplateDraft EmailTemplateStatus = "draft"
+	// EmailTemplatePublished é a versão enviada; existe no máximo uma por email e idioma
+	EmailTemplatePublished EmailTemplateStatus = "published"
+	// EmailTemplateArchived é uma versão substituída ou retirada, que pode voltar a ser publicada
+	EmailTemplateArchived EmailTemplateStatus = "archived"
+)
+
+// Limites da identidade visual e dos modelos de email
+const (
+	MaxTenantBrandingNameSize   = 100
+	MaxTenantBrandingFooterSize = 500
+	MaxTenantBrandingURLSize    = 2048
+	MaxEmailTemplateSubjectSize = 200
+	MaxEmailTemplateHTMLSize    = 100000
+	MaxEmailTemplateTextSize    = 20000
+)
+
+// Valores padrão da identidade visual dos tenants que não a configuraram
+const (
+	DefaultTenantBrandingName           = "INNOVABIZ"
+	DefaultTenantBrandingPrimaryColor   = "#0B3D91"
+	DefaultTenantBrandingSecondaryColor = "#F4F6F8"
+)
+
+// Erros da identidade visual e dos modelos de email
+var (
+	ErrInvalidTenantBranding        = errors.New("identidade visual do tenant inválida")
+	ErrInvalidEmailTemplate         = errors.New("modelo de email inválido")
+	ErrEmailTemplateNotFound        = errors.New("modelo de email não encontrado")
+	ErrUnknownEmailTemplateVariable = errors.New("variável de modelo de email desconhecida")
+)
+
+// brandingColorPattern aceita as cores hexadecimais #RGB e #RRGGBB
+var brandingColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// TenantBranding representa a identidade visual do tenant usada nos emails
+// Os campos vazios usam os valores padrão ou são omitidos pelos modelos
+type TenantBranding struct {
+	TenantID       uuid.UUID `json:"tenant_id"`
+	DisplayName    string    `json:"display_name,omitempty"`
+	LogoURL        string    `json:"logo_url,omitempty"`
+	PrimaryColor   string    `json:"primary_color,omitempty"`
+	SecondaryColor string    `json:"secondary_color,omitempty"`
+	SupportEmail   string    `json:"support_email,omitempty"`
+	SupportURL     string    `json:"support_url,omitempty"`
+	FooterText     string    `json:"footer_text,omitempty"`
+	UpdatedBy      uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultTenantBranding retorna a identidade visual de um tenant que ainda não a configurou
+func DefaultTenantBranding(tenantID uuid.UUID) *TenantBranding {
+	return &TenantBranding{
+		TenantID:       tenantID,
+		DisplayName:    DefaultTenantBrandingName,
+		PrimaryColor:   DefaultTenantBrandingPrimaryColor,
+		SecondaryColor: DefaultTenantBrandingSecondaryColor,
+	}
+}
+
+// Validate verifica os campos da identidade visual
+// O logótipo e a página de suporte têm de ser endereços HTTPS, para que os clientes de email os apresentem
+func (b *TenantBranding) Validate() error {
+	if b.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	b.DisplayName = strings.TrimSpace(b.DisplayName)
+	if b.DisplayName == "" || utf8.RuneCountInString(b.DisplayName) > MaxTenantBrandingNameSize || strings.ContainsAny(b.DisplayName, "\r\n") {
+		return fmt.Errorf("%w: o nome é obrigatório e tem no máximo %d caracteres numa linha", ErrInvalidTenantBranding, MaxTenantBrandingNameSize)
+	}
+	for name, value := range map[string]string{"logo_url": b.LogoURL, "support_url": b.SupportURL} {
+		if value == "" {
+			continue
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" || len(value) > MaxTenantBrandingURLSize {
+			return fmt.Errorf("%w: %s tem de ser um endereço https", ErrInvalidTenantBranding, name)
+		}
+	}
+	for name, value := range map[string]string{"primary_color": b.PrimaryColor, "secondary_color": b.SecondaryColor} {
+		if value != "" && !brandingColorPattern.MatchString(value) {
+			return fmt.Errorf("%w: %s tem de ser uma cor #RGB ou #RRGGBB", ErrInvalidTenantBranding, name)
+		}
+	}
+	if b.SupportEmail != "" {
+		if address, err := mail.ParseAddress(b.SupportEmail); err != nil || address.Address != b.SupportEmail {
+			return fmt.Errorf("%w: email de suporte inválido", ErrInvalidTenantBranding)
+		}
+	}
+	if utf8.RuneCountInString(b.FooterText) > MaxTenantBrandingFooterSize {
+		return fmt.Errorf("%w: o rodapé tem no máximo %d caracteres", ErrInvalidTenantBranding, MaxTenantBrandingFooterSize)
+	}
+	return nil
+}
+
+// templateValues retorna os campos da identidade visual disponíveis nos modelos em .Brand
+func (b *TenantBranding) templateValues() map[string]string {
+	values := map[string]string{
+		"Name":           b.DisplayName,
+		"LogoURL":        b.LogoURL,
+		"PrimaryColor":   b.PrimaryColor,
+		"SecondaryColor": b.SecondaryColor,
+		"SupportEmail":   b.SupportEmail,
+		"SupportURL":     b.SupportURL,
+		"FooterText":     b.FooterText,
+	}
+	defaults := map[string]string{
+		"Name":           DefaultTenantBrandingName,
+		"PrimaryColor":   DefaultTenantBrandingPrimaryColor,
+		"SecondaryColor": DefaultTenantBrandingSecondaryColor,
+	}
+	for name, value := range defaults {
+		if values[name] == "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// EmailTemplateVariable descreve uma variável disponível nos modelos de email
+type EmailTemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// emailTemplateCommonVariables estão disponíveis em todos os emails
+var emailTemplateCommonVariables = []EmailTemplateVariable{
+	{Name: "Name", Description: "Nome de apresentação do destinatário", Example: "Ana Silva"},
+	{Name: "Email", Description: "Email do destinatário", Example: "ana.silva@example.com"},
+	{Name: "ActionURL", Description: "Link da ação pedida ao destinatário", Example: "https://example.com/self-service?token=x"},
+	{Name: "ExpiresAt", Description: "Validade do link no fuso horário do destinatário", Example: "17/10/2026 09:30 WAT"},
+}
+
+// emailTemplateKindVariables são as variáveis próprias de cada email
+var emailTemplateKindVariables = map[EmailTemplateKind][]EmailTemplateVariable{
+	EmailTemplateKindInvite: {
+		{Name: "InvitedBy", Description: "Nome de quem convidou o destinatário", Example: "Carlos Mendes"},
+	},
+	EmailTemplateKindPasswordReset: {},
+	EmailTemplateKindMFAEnrollment: {
+		{Name: "Method", Description: "Método de autenticação a configurar", Example: "TOTP"},
+	},
+}
+
+// emailTemplateBrandVariables são os campos da identidade visual, em .Brand
+var emailTemplateBrandVariables = []EmailTemplateVariable{
+	{Name: "Brand.Name", Description: "Nome do tenant", Example: "Banco Exemplo"},
+	{Name: "Brand.LogoURL", Description: "Endereço do logótipo; vazio quando não configurado", Example: "https://example.com/logo.png"},
+	{Name: "Brand.PrimaryColor", Description: "Cor principal", Example: DefaultTenantBrandingPrimaryColor},
+	{Name: "Brand.SecondaryColor", Description: "Cor secundária", Example: DefaultTenantBrandingSecondaryColor},
+	{Name: "Brand.SupportEmail", Description: "Email de suporte; vazio quando não configurado", Example: "suporte@example.com"},
+	{Name: "Brand.SupportURL", Description: "Página de suporte; vazia quando não configurada", Example: "https://example.com/suporte"},
+	{Name: "Brand.FooterText", Description: "Texto do rodapé; vazio quando não configurado", Example: "Banco Exemplo, S.A."},
+}
+
+// EmailTemplateVariables retorna as variáveis disponíveis nos modelos do email
+func EmailTemplateVariables(kind EmailTemplateKind) []EmailTemplateVariable {
+	variables := append([]EmailTemplateVariable{}, emailTemplateCommonVariables...)
+	variables = append(variables, emailTemplateKindVariables[kind]...)
+	return append(variables, emailTemplateBrandVariables...)
+}
+
+// EmailTemplateData contém os valores das variáveis de um email
+// Apenas as variáveis do email são expostas ao modelo; as restantes são ignoradas
+type EmailTemplateData struct {
+	Name      string
+	Email     string
+	ActionURL string
+	ExpiresAt string
+	// InvitedBy é usado apenas nos convites
+	InvitedBy string
+	// Method é usado apenas na inscrição de MFA
+	Method string
+}
+
+// SampleEmailTemplateData retorna os valores de exemplo das variáveis, usados na validação e na pré-visualização
+func SampleEmailTemplateData() *EmailTemplateData {
+	data := &EmailTemplateData{}
+	for _, variables := range [][]EmailTemplateVariable{emailTemplateCommonVariables, emailTemplateKindVariables[EmailTemplateKindInvite], emailTemplateKindVariables[EmailTemplateKindMFAEnrollment]} {
+		for _, variable := range variables {
+			_ = data.Set(variable.Name, variable.Example)
+		}
+	}
+	return data
+}
+
+// Set altera o valor de uma variável pelo nome
+func (d *EmailTemplateData) Set(name, value string) error {
+	switch name {
+	case "Name":
+		d.Name = value
+	case "Email":
+		d.Email = value
+	case "ActionURL":
+		d.ActionURL = value
+	case "ExpiresAt":
+		d.ExpiresAt = value
+	case "InvitedBy":
+		d.InvitedBy = value
+	case "Method":
+		d.Method = value
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownEmailTemplateVariable, name)
+	}
+	return nil
+}
+
+// values retorna as variáveis do email com a identidade visual em .Brand
+// Os modelos são executados com missingkey=error, pelo que uma variável de outro email é recusada
+func (d *EmailTemplateData) values(kind EmailTemplateKind, branding *TenantBranding) map[string]interface{} {
+	all := map[string]string{
+		"Name":      d.Name,
+		"Email":     d.Email,
+		"ActionURL": d.ActionURL,
+		"ExpiresAt": d.ExpiresAt,
+		"InvitedBy": d.InvitedBy,
+		"Method":    d.Method,
+	}
+	values := make(map[string]interface{})
+	for _, variable := range emailTemplateCommonVariables {
+		values[variable.Name] = all[variable.Name]
+	}
+	for _, variable := range emailTemplateKindVariables[kind] {
+		values[variable.Name] = all[variable.Name]
+	}
+	values["Brand"] = branding.templateValues()
+	return values
+}
+
+// EmailTemplate representa uma versão do modelo de um email num idioma
+// O assunto e o texto são modelos text/template e o HTML é um modelo html/template, com as
+// variáveis de EmailTemplateVariables. As versões publicadas ou arquivadas não são alteradas
+type EmailTemplate struct {
+	ID          uuid.UUID           `json:"id"`
+	TenantID    uuid.UUID           `json:"tenant_id"`
+	Kind        EmailTemplateKind   `json:"kind"`
+	Locale      string              `json:"locale"`
+	Version     int                 `json:"version"`
+	Status      EmailTemplateStatus `json:"status"`
+	Description string              `json:"description,omitempty"`
+	Subject     string              `json:"subject"`
+	HTMLBody    string              `json:"html_body"`
+	// TextBody é a alternativa em texto simples; vazia, o email é enviado apenas em HTML
+	TextBody    string     `json:"text_body,omitempty"`
+	CreatedBy   uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PublishedBy *uuid.UUID `json:"published_by,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// Validate verifica os campos, sanitiza o HTML e compila o modelo contra dados de exemplo, para
+// que um modelo com variáveis inexistentes seja recusado na gravação e não no envio
+func (t *EmailTemplate) Validate() error {
+	if t.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !t.Kind.IsValid() {
+		return fmt.Errorf("%w: email desconhecido %q", ErrInvalidEmailTemplate, t.Kind)
+	}
+	if t.Locale = NormalizeLoginNotificationLocale(t.Locale); t.Locale == "" {
+		return fmt.Errorf("%w: idioma inválido", ErrInvalidEmailTemplate)
+	}
+	t.Subject = strings.TrimSpace(t.Subject)
+	if t.Subject == "" || utf8.RuneCountInString(t.Subject) > MaxEmailTemplateSubjectSize || strings.ContainsAny(t.Subject, "\r\n") {
+		return fmt.Errorf("%w: o assunto é obrigatório e tem no máximo %d caracteres numa linha", ErrInvalidEmailTemplate, MaxEmailTemplateSubjectSize)
+	}
+	if strings.TrimSpace(t.HTMLBody) == "" || len(t.HTMLBody) > MaxEmailTemplateHTMLSize {
+		return fmt.Errorf("%w: o HTML é obrigatório e tem no máximo %d bytes", ErrInvalidEmailTemplate, MaxEmailTemplateHTMLSize)
+	}
+	if utf8.RuneCountInString(t.TextBody) > MaxEmailTemplateTextSize {
+		return fmt.Errorf("%w: o texto tem no máximo %d caracteres", ErrInvalidEmailTemplate, MaxEmailTemplateTextSize)
+	}
+
+	t.HTMLBody = SanitizeEmailHTML(t.HTMLBody)
+	if strings.TrimSpace(t.HTMLBody) == "" {
+		return fmt.Errorf("%w: o HTML não tem conteúdo permitido", ErrInvalidEmailTemplate)
+	}
+	if _, err := t.Render(SampleEmailTemplateData(), DefaultTenantBranding(t.TenantID)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEmailTemplate, err)
+	}
+	return nil
+}
+
+// RenderedEmail é um email produzido a partir de um modelo
+type RenderedEmail struct {
+	Kind   EmailTemplateKind `json:"kind"`
+	Locale string            `json:"locale"`
+	// TemplateID e Version identificam a versão do tenant; vazios no modelo padrão
+	TemplateID *uuid.UUID `json:"template_id,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	Builtin    bool       `json:"builtin"`
+	Subject    string     `json:"subject"`
+	HTML       string     `json:"html"`
+	Text       string     `json:"text,omitempty"`
+}
+
+// Render produz o assunto, o HTML e o texto do email com a identidade visual do tenant
+// O HTML é sanitizado antes de compilado, também nas versões gravadas antes de uma alteração das
+// regras de sanitização, e as variáveis são escapadas pelo html/template conforme o contexto
+func (t *EmailTemplate) Render(data *EmailTemplateData, branding *TenantBranding) (*RenderedEmail, error) {
+	values := data.values(t.Kind, branding)
+
+	subject, err := renderEmailText("subject", t.Subject, values)
+	if err != nil {
+		return nil, err
+	}
+	// As variáveis podem ter quebras de linha, que num cabeçalho permitiriam acrescentar outros
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	htmlTmpl, err := htmltemplate.New("html").Option("missingkey=error").Parse(SanitizeEmailHTML(t.HTMLBody))
+	if err != nil {
+		return nil, err
+	}
+	var htmlOut bytes.Buffer
+	if err := htmlTmpl.Execute(&htmlOut, values); err != nil {
+		return nil, err
+	}
+
+	text, err := renderEmailText("text", t.TextBody, values)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &RenderedEmail{
+		Kind:    t.Kind,
+		Locale:  t.Locale,
+		Version: t.Version,
+		Builtin: t.ID == uuid.Nil,
+		Subject: subject,
+		HTML:    htmlOut.String(),
+		Text:    text,
+	}
+	if !rendered.Builtin {
+		id := t.ID
+		rendered.TemplateID = &id
+	}
+	return rendered, nil
+}
+
+// renderEmailText compila e executa um modelo de texto, recusando variáveis inexistentes
+func renderEmailText(name, text string, values map[string]interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// builtinEmailLocale contém as frases comuns dos emails padrão de um idioma
+type builtinEmailLocale struct {
+	Greeting string
+	Expiry   string
+	Support  string
+}
+
+// builtinEmailMessage contém as frases de um email padrão num idioma
+type builtinEmailMessage struct {
+	Subject string
+	Intro   string
+	Action  string
+	Ignore  string
+}
+
+// builtinEmailLocales são as frases comuns dos emails padrão, pelos idiomas dos catálogos da API
+var builtinEmailLocales = map[string]builtinEmailLocale{
+	"pt-PT": {Greeting: "Olá {{.Name}},", Expiry: "Este link é válido até {{.ExpiresAt}}.", Support: "Precisa de ajuda? Contacte"},
+	"pt-BR": {Greeting: "Olá {{.Name}},", Expiry: "Este link é válido até {{.ExpiresAt}}.", Support: "Precisa de ajuda? Fale com"},
+	"en":    {Greeting: "Hello {{.Name}},", Expiry: "This link is valid until {{.ExpiresAt}}.", Support: "Need help? Contact"},
+	"es":    {Greeting: "Hola {{.Name}},", Expiry: "Este enlace es válido hasta el {{.ExpiresAt}}.", Support: "¿Necesita ayuda? Contacte con"},
+	"fr":    {Greeting: "Bonjour {{.Name}},", Expiry: "Ce lien est valable jusqu'au {{.ExpiresAt}}.", Support: "Besoin d'aide ? Contactez"},
+}
+
+// builtinEmailMessages são os emails usados quando o tenant não publicou um modelo no idioma
+var builtinEmailMessages = map[EmailTemplateKind]map[string]builtinEmailMessage{
+	EmailTemplateKindInvite: {
+		"pt-PT": {
+			Subject: "Convite para {{.Brand.Name}}",
+			Intro:   "{{.InvitedBy}} convidou-o a aderir a {{.Brand.Name}}. Aceite o convite para ativar a sua conta.",
+			Action:  "Aceitar convite",
+			Ignore:  "Se não esperava este convite, pode ignorar este email.",
+		},
+		"pt-BR": {
+			Subject: "Convite para {{.Brand.Name}}",
+			Intro:   "{{.InvitedBy}} convidou você para participar de {{.Brand.Name}}. Aceite o convite para ativar sua conta.",
+			Action:  "Aceitar convite",
+			Ignore:  "Se você não esperava este convite, pode ignorar este email.",
+		},
+		"en": {
+			Subject: "You're invited to {{.Brand.Name}}",
+			Intro:   "{{.InvitedBy}} has invited you to join {{.Brand.Name}}. Accept the invitation to activate your account.",
+			Action:  "Accept invitation",
+			Ignore:  "If you weren't expecting this invitation, you can ignore this email.",
+		},
+		"es": {
+			Subject: "Invitación a {{.Brand.Name}}",
+			Intro:   "{{.InvitedBy}} le ha invitado a unirse a {{.Brand.Name}}. Acepte la invitación para activar su cuenta.",
+			Action:  "Aceptar invitación",
+			Ignore:  "Si no esperaba esta invitación, puede ignorar este correo.",
+		},
+		"fr": {
+			Subject: "Invitation à rejoindre {{.Brand.Name}}",
+			Intro:   "{{.InvitedBy}} vous invite à rejoindre {{.Brand.Name}}. Acceptez l'invitation pour activer votre compte.",
+			Action:  "Accepter l'invitation",
+			Ignore:  "Si vous n'attendiez pas cette invitation, vous pouvez ignorer cet e-mail.",
+		},
+	},
+	EmailTemplateKindPasswordReset: {
+		"pt-PT": {
+			Subject: "Redefinição da senha da sua conta {{.Brand.Name}}",
+			Intro:   "Recebemos um pedido para redefinir a senha da sua conta.",
+			Action:  "Redefinir senha",
+			Ignore:  "Se não pediu a redefinição, ignore este email; a sua senha não será alterada.",
+		},
+		"pt-BR": {
+			Subject: "Redefinição de senha da sua conta {{.Brand.Name}}",
+			Intro:   "Recebemos uma solicitação para redefinir a senha da sua conta.",
+			Action:  "Redefinir senha",
+			Ignore:  "Se você não solicitou a redefinição, ignore este email; sua senha não será alterada.",
+		},
+		"en": {
+			Subject: "Reset your {{.Brand.Name}} password",
+			Intro:   "We received a request to reset the password for your account.",
+			Action:  "Reset password",
+			Ignore:  "If you didn't request a reset, ignore this email; your password won't change.",
+		},
+		"es": {
+			Subject: "Restablecimiento de la contraseña de {{.Brand.Name}}",
+			Intro:   "Hemos recibido una solicitud para restablecer la contraseña de su cuenta.",
+			Action:  "Restablecer contraseña",
+			Ignore:  "Si no solicitó el restablecimiento, ignore este correo; su contraseña no cambiará.",
+		},
+		"fr": {
+			Subject: "Réinitialisation de votre mot de passe {{.Brand.Name}}",
+			Intro:   "Nous avons reçu une demande de réinitialisation du mot de passe de votre compte.",
+			Action:  "Réinitialiser le mot de passe",
+			Ignore:  "Si vous n'êtes pas à l'origine de cette demande, ignorez cet e-mail ; votre mot de passe ne changera pas.",
+		},
+	},
+	EmailTemplateKindMFAEnrollment: {
+		"pt-PT": {
+			Subject: "Configure a autenticação multifator em {{.Brand.Name}}",
+			Intro:   "Para proteger a sua conta, configure a autenticação multifator ({{.Method}}).",
+			Action:  "Configurar autenticação",
+			Ignore:  "Se não reconhece este pedido, contacte o suporte.",
+		},
+		"pt-BR": {
+			Subject: "Configure a autenticação multifator em {{.Brand.Name}}",
+			Intro:   "Para proteger sua conta, configure a autenticação multifator ({{.Method}}).",
+			Action:  "Configurar autenticação",
+			Ignore:  "Se você não reconhece esta solicitação, fale com o suporte.",
+		},
+		"en": {
+			Subject: "Set up multi-factor authentication for {{.Brand.Name}}",
+			Intro:   "To protect your account, set up multi-factor authentication ({{.Method}}).",
+			Action:  "Set up authentication",
+			Ignore:  "If you don't recognise this request, contact support.",
+		},
+		"es": {
+			Subject: "Configure la autenticación multifactor en {{.Brand.Name}}",
+			Intro:   "Para proteger su cuenta, configure la autenticación multifactor ({{.Method}}).",
+			Action:  "Configurar autenticación",
+			Ignore:  "Si no reconoce esta solicitud, contacte con soporte.",
+		},
+		"fr": {
+			Subject: "Configurez l'authentification multifacteur sur {{.Brand.Name}}",
+			Intro:   "Pour protéger votre compte, configurez l'authentification multifacteur ({{.Method}}).",
+			Action:  "Configurer l'authentification",
+			Ignore:  "Si vous ne reconnaissez pas cette demande, contactez le support.",
+		},
+	},
+}
+
+// builtinEmailHTMLLayout é o HTML dos emails padrão, com a identidade visual do tenant
+// Os marcadores %[n]s recebem o idioma e as frases do email
+const builtinEmailHTMLLayout = `<!DOCTYPE html>
+<html lang="%[1]s">
+<body style="margin:0;padding:0;background-color:{{.Brand.SecondaryColor}};font-family:Arial,Helvetica,sans-serif;">
+<table role="presentation" width="100%%" cellpadding="0" cellspacing="0">
+<tr><td align="center" style="padding:24px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" style="background-color:#ffffff;">
+<tr><td style="padding:24px;background-color:{{.Brand.PrimaryColor}};">{{if .Brand.LogoURL}}<img src="{{.Brand.LogoURL}}" alt="{{.Brand.Name}}" height="40">{{else}}<strong style="color:#ffffff;font-size:20px;">{{.Brand.Name}}</strong>{{end}}</td></tr>
+<tr><td style="padding:24px;color:#1f2933;font-size:15px;line-height:1.5;">
+<p>%[2]s</p>
+<p>%[3]s</p>
+<p style="text-align:center;"><a href="{{.ActionURL}}" style="display:inline-block;padding:12px 24px;background-color:{{.Brand.PrimaryColor}};color:#ffffff;text-decoration:none;">%[4]s</a></p>
+<p>%[5]s</p>
+<p>%[6]s</p>
+</td></tr>
+<tr><td style="padding:16px 24px;color:#6b7280;font-size:12px;">{{if .Brand.FooterText}}{{.Brand.FooterText}}<br>{{end}}{{if .Brand.SupportEmail}}%[7]s <a href="mailto:{{.Brand.SupportEmail}}">{{.Brand.SupportEmail}}</a>{{end}}</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`
+
+// builtinEmailTextLayout é o texto dos emails padrão
+const builtinEmailTextLayout = `%[1]s
+
+%[2]s
+
+%[3]s: {{.ActionURL}}
+
+%[4]s
+%[5]s
+{{if .Brand.FooterText}}
+{{.Brand.FooterText}}
+{{end}}`
+
+// BuiltinEmailTemplate retorna o email padrão no idioma
+// Sem email no idioma, usa o da mesma língua (ex.: "pt-PT" para "pt" ou "pt-AO") e, por fim, o inglês
+func BuiltinEmailTemplate(kind EmailTemplateKind, locale string) *EmailTemplate {
+	messages := builtinEmailMessages[kind]
+	message, ok := messages[locale]
+	if !ok {
+		language := strings.SplitN(locale, "-", 2)[0]
+		for _, candidate := range []string{language, language + "-PT"} {
+			if message, ok = messages[candidate]; ok {
+				locale = candidate
+				break
+			}
+		}
+	}
+	if !ok {
+		locale = DefaultLoginNotificationLocale
+		message = messages[locale]
+	}
+	common := builtinEmailLocales[locale]
+
+	return &EmailTemplate{
+		Kind:    kind,
+		Locale:  locale,
+		Status:  EmailTemplatePublished,
+		Subject: message.Subject,
+		HTMLBody: fmt.Sprintf(builtinEmailHTMLLayout,
+			locale, common.Greeting, message.Intro, message.Action, common.Expiry, message.Ignore, common.Support),
+		TextBody: fmt.Sprintf(builtinEmailTextLayout,
+			common.Greeting, message.Intro, message.Action, common.Expiry, message.Ignore),
+	}
+}
@@ -0,0 +1,425 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Exportação dos dados IAM de um tenant na saída da plataforma.
+ * O arquivo gerado contém usuários, funções, permissões, atribuições e trilha de auditoria,
+ * em JSON Lines ou CSV, com tratamento de dados pessoais conforme o mercado e um manifesto
+ * assinado com o resumo SHA-256 de cada ficheiro. A geração avança por lotes e guarda o
+ * cursor de cada secção, podendo ser retomada após uma falha sem recomeçar.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantExportFormat representa o formato dos ficheiros de dados do arquivo
+type TenantExportFormat string
+
+// Formatos suportados
+const (
+	// Um objeto JSON por linha (JSON Lines), com as colunas da secção como campos
+	TenantExportFormatJSON TenantExportFormat = "json"
+	// CSV RFC 4180 com linha de cabeçalho; colunas estruturadas são serializadas em JSON
+	TenantExportFormatCSV TenantExportFormat = "csv"
+)
+
+// IsValid indica se o formato é conhecido
+func (f TenantExportFormat) IsValid() bool {
+	return f == TenantExportFormatJSON || f == TenantExportFormatCSV
+}
+
+// Extension retorna a extensão dos ficheiros de dados no formato
+func (f TenantExportFormat) Extension() string {
+	if f == TenantExportFormatCSV {
+		return "csv"
+	}
+	return "jsonl"
+}
+
+// TenantExportPIIMode define o tratamento dos dados pessoais no arquivo
+type TenantExportPIIMode string
+
+// Tratamentos de dados pessoais, do menos para o mais restritivo
+const (
+	// Os dados pessoais são exportados sem alteração
+	TenantExportPIIInclude TenantExportPIIMode = "include"
+	// Os dados pessoais são parcialmente ocultados (ex.: j***@exemplo.ao)
+	TenantExportPIIMask TenantExportPIIMode = "mask"
+	// Os dados pessoais são substituídos por pseudónimos HMAC estáveis dentro do arquivo
+	TenantExportPIIPseudonymize TenantExportPIIMode = "pseudonymize"
+)
+
+// tenantExportPIIStrictness ordena os tratamentos pelo nível de proteção
+var tenantExportPIIStrictness = map[TenantExportPIIMode]int{
+	TenantExportPIIInclude:      1,
+	TenantExportPIIMask:         2,
+	TenantExportPIIPseudonymize: 3,
+}
+
+// IsValid indica se o tratamento é conhecido
+func (m TenantExportPIIMode) IsValid() bool {
+	_, ok := tenantExportPIIStrictness[m]
+	return ok
+}
+
+// tenantExportMarketPIIModes define o tratamento mínimo dos dados pessoais por mercado
+// UE (GDPR) e Brasil (LGPD) exigem pseudonimização; Angola (Lei 22/11) e Moçambique exigem ocultação
+var tenantExportMarketPIIModes = map[string]TenantExportPIIMode{
+	"eu":         TenantExportPIIPseudonymize,
+	"brazil":     TenantExportPIIPseudonymize,
+	"angola":     TenantExportPIIMask,
+	"mozambique": TenantExportPIIMask,
+}
+
+// DefaultTenantExportPIIMode retorna o tratamento mínimo dos dados pessoais no mercado
+// Os mercados sem regra própria exportam os dados pessoais sem alteração
+func DefaultTenantExportPIIMode(market string) TenantExportPIIMode {
+	if mode, ok := tenantExportMarketPIIModes[strings.ToLower(market)]; ok {
+		return mode
+	}
+	return TenantExportPIIInclude
+}
+
+// ResolveTenantExportPIIMode retorna o tratamento a aplicar no mercado
+// O pedido pode escolher um tratamento mais restritivo do que o do mercado, nunca menos
+func ResolveTenantExportPIIMode(market string, requested TenantExportPIIMode) (TenantExportPIIMode, error) {
+	minimum := DefaultTenantExportPIIMode(market)
+	if requested == "" {
+		return minimum, nil
+	}
+	if !requested.IsValid() {
+		return "", fmt.Errorf("%w: tratamento de dados pessoais desconhecido %q", ErrInvalidTenantExport, requested)
+	}
+	if tenantExportPIIStrictness[requested] < tenantExportPIIStrictness[minimum] {
+		return "", fmt.Errorf("%w: o mercado %s exige o tratamento %s dos dados pessoais", ErrInvalidTenantExport, market, minimum)
+	}
+	return requested, nil
+}
+
+// TenantExportStatus representa o estado de uma exportação
+type TenantExportStatus string
+
+// Estados de uma exportação
+const (
+	TenantExportStatusPending   TenantExportStatus = "pending"
+	TenantExportStatusRunning   TenantExportStatus = "running"
+	TenantExportStatusCompleted TenantExportStatus = "completed"
+	TenantExportStatusFailed    TenantExportStatus = "failed"
+)
+
+// TenantExportSection identifica um conjunto de dados do arquivo
+type TenantExportSection string
+
+// Secções do arquivo, pela ordem de geração
+const (
+	TenantExportSectionUsers                   TenantExportSection = "users"
+	TenantExportSectionRoles                   TenantExportSection = "roles"
+	TenantExportSectionPermissions             TenantExportSection = "permissions"
+	TenantExportSectionRolePermissions         TenantExportSection = "role_permissions"
+	TenantExportSectionUserRoles               TenantExportSection = "user_roles"
+	TenantExportSectionUserPermissions         TenantExportSection = "user_permissions"
+	TenantExportSectionAuditLogs               TenantExportSection = "audit_logs"
+	TenantExportSectionRoleEvents              TenantExportSection = "role_events"
+	TenantExportSectionUserLifecycleTransition TenantExportSection = "user_lifecycle_transitions"
+	TenantExportSectionSecurityIncidents       TenantExportSection = "security_incidents"
+)
+
+// TenantExportSections lista as secções do arquivo pela ordem de geração
+var TenantExportSections = []TenantExportSection{
+	TenantExportSectionUsers,
+	TenantExportSectionRoles,
+	TenantExportSectionPermissions,
+	TenantExportSectionRolePermissions,
+	TenantExportSectionUserRoles,
+	TenantExportSectionUserPermissions,
+	TenantExportSectionAuditLogs,
+	TenantExportSectionRoleEvents,
+	TenantExportSectionUserLifecycleTransition,
+	TenantExportSectionSecurityIncidents,
+}
+
+// TenantExportColumn descreve uma coluna de uma secção do arquivo
+type TenantExportColumn struct {
+	Name string
+	// Dado pessoal sujeito ao tratamento da exportação
+	PII bool
+	// Valor estruturado; no CSV é serializado em JSON e, fora do modo include,
+	// os campos pessoais que contém são tratados
+	Structured bool
+}
+
+// tenantExportColumns define as colunas de cada secção, pela ordem em que são exportadas
+// Alterações a estas colunas alteram o formato documentado em docs/integracao/ExportacaoTenant.md
+var tenantExportColumns = map[TenantExportSection][]TenantExportColumn{
+	TenantExportSectionUsers: {
+		{Name: "id"}, {Name: "username", PII: true}, {Name: "email", PII: true}, {Name: "email_verified"},
+		{Name: "first_name", PII: true}, {Name: "last_name", PII: true}, {Name: "display_name", PII: true},
+		{Name: "phone_number", PII: true}, {Name: "phone_verified"}, {Name: "profile_picture_url", PII: true},
+		{Name: "locale"}, {Name: "timezone"}, {Name: "status"}, {Name: "status_reason"},
+		{Name: "metadata", Structured: true}, {Name: "login_count"}, {Name: "last_login_at"},
+		{Name: "created_at"}, {Name: "updated_at"}, {Name: "deleted_at"},
+	},
+	TenantExportSectionRoles: {
+		{Name: "id"}, {Name: "name"}, {Name: "description"}, {Name: "is_system"},
+		{Name: "created_at"}, {Name: "updated_at"},
+	},
+	TenantExportSectionPermissions: {
+		{Name: "id"}, {Name: "resource"}, {Name: "action"}, {Name: "description"}, {Name: "is_system"},
+		{Name: "created_at"}, {Name: "updated_at"},
+	},
+	TenantExportSectionRolePermissions: {
+		{Name: "role_id"}, {Name: "permission_id"}, {Name: "created_at"},
+	},
+	TenantExportSectionUserRoles: {
+		{Name: "user_id"}, {Name: "role_id"}, {Name: "created_at"}, {Name: "created_by"},
+	},
+	TenantExportSectionUserPermissions: {
+		{Name: "user_id"}, {Name: "permission_id"}, {Name: "expires_at"}, {Name: "granted_by"},
+		{Name: "access_request_id"}, {Name: "granted_at"},
+	},
+	TenantExportSectionAuditLogs: {
+		{Name: "id"}, {Name: "event_type"}, {Name: "entity_type"}, {Name: "entity_id"}, {Name: "user_id"},
+		{Name: "ip_address", PII: true}, {Name: "user_agent", PII: true},
+		{Name: "old_value", Structured: true}, {Name: "new_value", Structured: true}, {Name: "metadata", Structured: true},
+		{Name: "created_at"},
+	},
+	TenantExportSectionRoleEvents: {
+		{Name: "id"}, {Name: "role_id"}, {Name: "version"}, {Name: "event_type"},
+		{Name: "payload", Structured: true}, {Name: "actor_id"}, {Name: "occurred_at"}, {Name: "recorded_at"},
+	},
+	TenantExportSectionUserLifecycleTransition: {
+		{Name: "id"}, {Name: "user_id"}, {Name: "from_status"}, {Name: "to_status"}, {Name: "reason"},
+		{Name: "actor_id"}, {Name: "occurred_at"}, {Name: "recorded_at"},
+	},
+	TenantExportSectionSecurityIncidents: {
+		{Name: "id"}, {Name: "rule"}, {Name: "severity"}, {Name: "status"}, {Name: "actor_id"},
+		{Name: "summary"}, {Name: "evidence", Structured: true}, {Name: "detected_at"}, {Name: "updated_at"},
+		{Name: "triaged_by"}, {Name: "resolution_note"}, {Name: "closed_at"},
+	},
+}
+
+// TenantExportPIIFields lista os campos pessoais tratados dentro dos valores estruturados
+var TenantExportPIIFields = []string{
+	"username", "email", "first_name", "last_name", "display_name", "name",
+	"phone_number", "phone", "profile_picture_url", "ip_address", "user_agent",
+}
+
+// IsValid indica se a secção é conhecida
+func (s TenantExportSection) IsValid() bool {
+	_, ok := tenantExportColumns[s]
+	return ok
+}
+
+// Columns retorna as colunas da secção, pela ordem em que são exportadas
+func (s TenantExportSection) Columns() []TenantExportColumn {
+	return tenantExportColumns[s]
+}
+
+// FileName retorna o nome do ficheiro da secção no formato indicado
+func (s TenantExportSection) FileName(format TenantExportFormat) string {
+	return string(s) + "." + format.Extension()
+}
+
+// TenantExportRecord representa uma linha de uma secção lida do repositório
+type TenantExportRecord struct {
+	// Chave da linha na ordem de leitura, usada como cursor para retomar a secção
+	Key string
+	// Valores alinhados com as colunas da secção
+	Values []interface{}
+}
+
+// TenantExportSectionProgress regista o progresso da geração de uma secção
+type TenantExportSectionProgress struct {
+	Section TenantExportSection `json:"section"`
+	// Chave da última linha gravada; a leitura seguinte continua a partir dela
+	Cursor  string `json:"cursor,omitempty"`
+	Records int64  `json:"records"`
+	// Tamanho confirmado do ficheiro; escritas além deste tamanho são descartadas ao retomar
+	Bytes int64 `json:"bytes"`
+	Done  bool  `json:"done"`
+}
+
+// Manifesto do arquivo
+const (
+	TenantExportManifestVersion  = "1"
+	TenantExportManifestFileName = "manifest.json"
+	TenantExportSignatureEd25519 = "Ed25519"
+)
+
+// TenantExportManifestFile descreve um ficheiro de dados do arquivo
+type TenantExportManifestFile struct {
+	Name    string              `json:"name"`
+	Section TenantExportSection `json:"section"`
+	Records int64               `json:"records"`
+	Bytes   int64               `json:"bytes"`
+	SHA256  string              `json:"sha256"`
+}
+
+// TenantExportManifest descreve o conteúdo do arquivo e garante a sua integridade
+// A assinatura cobre a serialização JSON do manifesto com o campo signature vazio
+type TenantExportManifest struct {
+	Version            string                     `json:"version"`
+	ExportID           uuid.UUID                  `json:"export_id"`
+	TenantID           uuid.UUID                  `json:"tenant_id"`
+	Market             string                     `json:"market"`
+	Format             TenantExportFormat         `json:"format"`
+	PIIMode            TenantExportPIIMode        `json:"pii_mode"`
+	GeneratedAt        time.Time                  `json:"generated_at"`
+	Files              []TenantExportManifestFile `json:"files"`
+	SignatureAlgorithm string                     `json:"signature_algorithm"`
+	KeyID              string                     `json:"key_id"`
+	Signature          string                     `json:"signature,omitempty"`
+}
+
+// SigningPayload retorna os bytes assinados do manifesto
+func (m TenantExportManifest) SigningPayload() ([]byte, error) {
+	m.Signature = ""
+	return json.Marshal(m)
+}
+
+// File retorna o ficheiro de dados com o nome indicado
+func (m *TenantExportManifest) File(name string) (TenantExportManifestFile, bool) {
+	for _, file := range m.Files {
+		if file.Name == name {
+			return file, true
+		}
+	}
+	return TenantExportManifestFile{}, false
+}
+
+// Erros da exportação de dados de tenants
+var (
+	ErrTenantExportNotFound   = errors.New("exportação de tenant não encontrada")
+	ErrInvalidTenantExport    = errors.New("exportação de tenant inválida")
+	ErrTenantExportInProgress = errors.New("já existe uma exportação em curso para o tenant")
+	ErrTenantExportNotReady   = errors.New("exportação de tenant ainda não concluída")
+	ErrTenantExportConflict   = errors.New("exportação de tenant alterada concorrentemente")
+)
+
+// TenantExport representa a geração do arquivo de dados de um tenant
+type TenantExport struct {
+	ID          uuid.UUID                     `json:"id"`
+	TenantID    uuid.UUID                     `json:"tenant_id"`
+	Market      string                        `json:"market"`
+	Format      TenantExportFormat            `json:"format"`
+	PIIMode     TenantExportPIIMode           `json:"pii_mode"`
+	Status      TenantExportStatus            `json:"status"`
+	Progress    []TenantExportSectionProgress `json:"progress"`
+	Manifest    *TenantExportManifest         `json:"manifest,omitempty"`
+	Error       string                        `json:"error,omitempty"`
+	RequestedBy uuid.UUID                     `json:"requested_by"`
+	Attempts    int                           `json:"attempts"`
+	Version     int64                         `json:"version"`
+	CreatedAt   time.Time                     `json:"created_at"`
+	UpdatedAt   time.Time                     `json:"updated_at"`
+	CompletedAt *time.Time                    `json:"completed_at,omitempty"`
+}
+
+// NewTenantExport cria uma exportação pendente com o tratamento de dados pessoais do mercado
+func NewTenantExport(
+	tenantID, requestedBy uuid.UUID,
+	market string,
+	format TenantExportFormat,
+	piiMode TenantExportPIIMode,
+	now time.Time,
+) (*TenantExport, error) {
+	if tenantID == uuid.Nil {
+		return nil, fmt.Errorf("%w: tenant obrigatório", ErrInvalidTenantExport)
+	}
+	market = strings.ToLower(strings.TrimSpace(market))
+	if market == "" {
+		return nil, fmt.Errorf("%w: mercado obrigatório", ErrInvalidTenantExport)
+	}
+	if format == "" {
+		format = TenantExportFormatJSON
+	}
+	if !format.IsValid() {
+		return nil, fmt.Errorf("%w: formato desconhecido %q", ErrInvalidTenantExport, format)
+	}
+	piiMode, err := ResolveTenantExportPIIMode(market, piiMode)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]TenantExportSectionProgress, len(TenantExportSections))
+	for i, section := range TenantExportSections {
+		progress[i] = TenantExportSectionProgress{Section: section}
+	}
+
+	return &TenantExport{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Market:      market,
+		Format:      format,
+		PIIMode:     piiMode,
+		Status:      TenantExportStatusPending,
+		Progress:    progress,
+		RequestedBy: requestedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// IsActive indica se a exportação aguarda ou está em geração
+func (e *TenantExport) IsActive() bool {
+	return e.Status == TenantExportStatusPending || e.Status == TenantExportStatusRunning
+}
+
+// CurrentSection retorna o progresso da primeira secção por concluir, ou nil quando todas estão concluídas
+func (e *TenantExport) CurrentSection() *TenantExportSectionProgress {
+	for i := range e.Progress {
+		if !e.Progress[i].Done {
+			return &e.Progress[i]
+		}
+	}
+	return nil
+}
+
+// Start marca o início de uma passagem de geração
+// Uma exportação em geração pode ser retomada após a interrupção do processo que a gerava
+func (e *TenantExport) Start(now time.Time) error {
+	if !e.IsActive() {
+		return fmt.Errorf("%w: estado atual %s", ErrInvalidTenantExport, e.Status)
+	}
+	e.Status = TenantExportStatusRunning
+	e.Attempts++
+	e.UpdatedAt = now
+	return nil
+}
+
+// Resume devolve à fila uma exportação falhada, mantendo o progresso das secções
+func (e *TenantExport) Resume(now time.Time) error {
+	if e.Status != TenantExportStatusFailed {
+		return fmt.Errorf("%w: apenas exportações falhadas podem ser retomadas, estado atual %s", ErrInvalidTenantExport, e.Status)
+	}
+	e.Status = TenantExportStatusPending
+	e.Error = ""
+	e.UpdatedAt = now
+	return nil
+}
+
+// Complete conclui a exportação com o manifesto assinado
+func (e *TenantExport) Complete(manifest *TenantExportManifest, now time.Time) {
+	completedAt := now
+	e.Status = TenantExportStatusCompleted
+	e.Manifest = manifest
+	e.Error = ""
+	e.UpdatedAt = now
+	e.CompletedAt = &completedAt
+}
+
+// Fail regista a falha da passagem; a exportação pode ser retomada a partir do último lote gravado
+func (e *TenantExport) Fail(reason string, now time.Time) {
+	e.Status = TenantExportStatusFailed
+	e.Error = reason
+	e.UpdatedAt = now
+}
@@ -0,0 +1,336 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Troca de tokens para delegação (OAuth 2.0 Token Exchange, RFC 8693): um serviço que age
+ * em nome de um usuário (ex.: o gateway de pagamentos a chamar o bureau de crédito) troca o
+ * token do usuário e o seu próprio token por um token de curta duração destinado a um único
+ * serviço, com os escopos reduzidos pela política dessa audiência e a claim act a identificar
+ * o serviço que age. Cada troca, concedida ou recusada, fica no registo de auditoria.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identificadores da troca de tokens (RFC 8693, secções 2.1 e 3)
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Limites das políticas de troca de tokens
+const (
+	MaxTokenExchangeTTL             = time.Hour
+	MaxTokenExchangeDelegationDepth = 5
+	MaxTokenExchangeScopes          = 50
+	MaxTokenExchangeActors          = 50
+)
+
+// tokenExchangeAudiencePattern restringe as audiências a identificadores de serviço ou URLs
+var tokenExchangeAudiencePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/-]{0,254}$`)
+
+// tokenExchangeScopePattern restringe os escopos aos caracteres permitidos pelo RFC 6749 (secção 3.3)
+var tokenExchangeScopePattern = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// ActorClaim é a claim act de um token delegado: identifica o serviço que age em nome do
+// titular e, numa delegação encadeada, o ator anterior em Actor
+type ActorClaim struct {
+	Subject  string      `json:"sub"`
+	ClientID string      `json:"client_id,omitempty"`
+	Actor    *ActorClaim `json:"act,omitempty"`
+}
+
+// Depth retorna o número de atores da cadeia de delegação
+func (a *ActorClaim) Depth() int {
+	depth := 0
+	for actor := a; actor != nil; actor = actor.Actor {
+		depth++
+	}
+	return depth
+}
+
+// Chain retorna os identificadores dos atores, do atual ao primeiro
+func (a *ActorClaim) Chain() []string {
+	var chain []string
+	for actor := a; actor != nil; actor = actor.Actor {
+		chain = append(chain, actor.Identifier())
+	}
+	return chain
+}
+
+// Identifier retorna o client_id do ator ou, na sua falta, o subject
+func (a *ActorClaim) Identifier() string {
+	if a.ClientID != "" {
+		return a.ClientID
+	}
+	return a.Subject
+}
+
+// TokenClaims são as claims dos tokens lidos e emitidos na troca, independentes do formato;
+// a codificação em JWT fica a cargo do application.TokenExchangeCodec
+type TokenClaims struct {
+	TokenID   string
+	Issuer    string
+	Subject   string
+	TenantID  string
+	Audience  []string
+	Scopes    []string // nil quando o token não delimita escopos
+	ClientID  string
+	Username  string
+	Market    string
+	MFALevel  string
+	SessionID string
+	AuthTime  *time.Time
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Actor     *ActorClaim
+	// Claims personalizadas dos atributos do usuário; as claims reservadas nunca são substituídas
+	CustomClaims map[string]interface{}
+}
+
+// ActorIdentifier retorna o client_id do token ou, na sua falta, o subject; é o identificador
+// com que o serviço que apresenta o token aparece nas políticas de troca
+func (c *TokenClaims) ActorIdentifier() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+	return c.Subject
+}
+
+// HasScopeClaim indica se o token delimita os escopos; os tokens de sessão dos usuários não o fazem
+func (c *TokenClaims) HasScopeClaim() bool {
+	return c.Scopes != nil
+}
+
+// TokenExchangePolicy define, para uma audiência do tenant, os serviços que podem obter tokens
+// delegados, os escopos máximos desses tokens, a sua validade e a profundidade de delegação
+type TokenExchangePolicy struct {
+	ID                 uuid.UUID `json:"id"`
+	TenantID           uuid.UUID `json:"tenant_id"`
+	Audience           string    `json:"audience"`
+	Description        string    `json:"description,omitempty"`
+	AllowedActors      []string  `json:"allowed_actors"`
+	AllowedScopes      []string  `json:"allowed_scopes"`
+	MaxTTLSeconds      int       `json:"max_ttl_seconds"`
+	MaxDelegationDepth int       `json:"max_delegation_depth"`
+	Enabled            bool      `json:"enabled"`
+	CreatedBy          uuid.UUID `json:"created_by"`
+	UpdatedBy          uuid.UUID `json:"updated_by"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Normalize remove espaços e duplicados dos atores e dos escopos e aplica os valores padrão
+func (p *TokenExchangePolicy) Normalize() {
+	p.Audience = strings.TrimSpace(p.Audience)
+	p.Description = strings.TrimSpace(p.Description)
+	p.AllowedActors = normalizeTokenExchangeValues(p.AllowedActors)
+	p.AllowedScopes = normalizeTokenExchangeValues(p.AllowedScopes)
+	if p.MaxDelegationDepth == 0 {
+		p.MaxDelegationDepth = 1
+	}
+}
+
+// Validate valida a política
+func (p *TokenExchangePolicy) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !tokenExchangeAudiencePattern.MatchString(p.Audience) {
+		return fmt.Errorf("%w: audiência inválida %q", ErrInvalidTokenExchangePolicy, p.Audience)
+	}
+	if len(p.AllowedActors) == 0 {
+		return fmt.Errorf("%w: pelo menos um serviço autorizado é obrigatório", ErrInvalidTokenExchangePolicy)
+	}
+	if len(p.AllowedActors) > MaxTokenExchangeActors {
+		return fmt.Errorf("%w: no máximo %d serviços autorizados", ErrInvalidTokenExchangePolicy, MaxTokenExchangeActors)
+	}
+	if len(p.AllowedScopes) == 0 {
+		return fmt.Errorf("%w: pelo menos um escopo é obrigatório", ErrInvalidTokenExchangePolicy)
+	}
+	if len(p.AllowedScopes) > MaxTokenExchangeScopes {
+		return fmt.Errorf("%w: no máximo %d escopos", ErrInvalidTokenExchangePolicy, MaxTokenExchangeScopes)
+	}
+	for _, scope := range p.AllowedScopes {
+		if !tokenExchangeScopePattern.MatchString(scope) {
+			return fmt.Errorf("%w: escopo inválido %q", ErrInvalidTokenExchangePolicy, scope)
+		}
+	}
+	if p.MaxTTLSeconds <= 0 || time.Duration(p.MaxTTLSeconds)*time.Second > MaxTokenExchangeTTL {
+		return fmt.Errorf("%w: a validade máxima deve estar entre 1 e %d segundos",
+			ErrInvalidTokenExchangePolicy, int(MaxTokenExchangeTTL/time.Second))
+	}
+	if p.MaxDelegationDepth < 1 || p.MaxDelegationDepth > MaxTokenExchangeDelegationDepth {
+		return fmt.Errorf("%w: a profundidade de delegação deve estar entre 1 e %d",
+			ErrInvalidTokenExchangePolicy, MaxTokenExchangeDelegationDepth)
+	}
+	return nil
+}
+
+// AllowsActor indica se o serviço pode obter tokens delegados para a audiência
+func (p *TokenExchangePolicy) AllowsActor(actor string) bool {
+	for _, allowed := range p.AllowedActors {
+		if allowed == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxTTL retorna a validade máxima dos tokens emitidos para a audiência
+func (p *TokenExchangePolicy) MaxTTL() time.Duration {
+	return time.Duration(p.MaxTTLSeconds) * time.Second
+}
+
+// Downscope calcula os escopos do token delegado: os pedidos, ou todos os disponíveis quando
+// nenhum é pedido, limitados aos escopos da política e, quando o token do titular delimita
+// escopos, também aos desse token. Um escopo pedido fora dos disponíveis recusa a troca
+func (p *TokenExchangePolicy) Downscope(subject *TokenClaims, requested []string) ([]string, error) {
+	available := make(map[string]bool, len(p.AllowedScopes))
+	for _, scope := range p.AllowedScopes {
+		available[scope] = true
+	}
+	if subject.HasScopeClaim() {
+		held := make(map[string]bool, len(subject.Scopes))
+		for _, scope := range subject.Scopes {
+			held[scope] = true
+		}
+		for scope := range available {
+			if !held[scope] {
+				delete(available, scope)
+			}
+		}
+	}
+
+	var granted []string
+	if len(requested) == 0 {
+		for scope := range available {
+			granted = append(granted, scope)
+		}
+	} else {
+		for _, scope := range normalizeTokenExchangeValues(requested) {
+			if !available[scope] {
+				return nil, fmt.Errorf("%w: %s", ErrTokenExchangeScopeNotAllowed, scope)
+			}
+			granted = append(granted, scope)
+		}
+	}
+	if len(granted) == 0 {
+		return nil, fmt.Errorf("%w: nenhum escopo disponível para a audiência", ErrTokenExchangeScopeNotAllowed)
+	}
+	sort.Strings(granted)
+	return granted, nil
+}
+
+// TokenExchangeOutcome representa o resultado de uma troca de tokens
+type TokenExchangeOutcome string
+
+// Resultados das trocas
+const (
+	TokenExchangeIssued TokenExchangeOutcome = "issued"
+	TokenExchangeDenied TokenExchangeOutcome = "denied"
+)
+
+// IsValid indica se o resultado é conhecido
+func (o TokenExchangeOutcome) IsValid() bool {
+	return o == TokenExchangeIssued || o == TokenExchangeDenied
+}
+
+// TokenExchangeEvent é um registo imutável de uma troca de tokens
+// Nas trocas recusadas, Reason indica o erro do RFC 8693 e os campos do token emitido ficam vazios
+type TokenExchangeEvent struct {
+	ID         uuid.UUID            `json:"id"`
+	TenantID   uuid.UUID            `json:"tenant_id"`
+	Subject    string               `json:"subject"`
+	Actor      string               `json:"actor"`
+	ActorChain []string             `json:"actor_chain,omitempty"`
+	Audience   string               `json:"audience"`
+	PolicyID   *uuid.UUID           `json:"policy_id,omitempty"`
+	Scopes     []string             `json:"scopes,omitempty"`
+	Outcome    TokenExchangeOutcome `json:"outcome"`
+	Reason     string               `json:"reason,omitempty"`
+	TokenID    string               `json:"token_id,omitempty"`
+	ExpiresAt  *time.Time           `json:"expires_at,omitempty"`
+	IPAddress  string               `json:"ip_address,omitempty"`
+	OccurredAt time.Time            `json:"occurred_at"`
+}
+
+// TokenExchangeEventFilter filtra o registo de auditoria das trocas de tokens
+type TokenExchangeEventFilter struct {
+	Subject  string
+	Actor    string
+	Audience string
+	Outcome  TokenExchangeOutcome
+	Since    *time.Time
+	Limit    int
+}
+
+// Códigos de erro da troca de tokens (RFC 6749, secção 5.2, e RFC 8693, secção 2.2.2)
+const (
+	TokenExchangeErrorInvalidRequest     = "invalid_request"
+	TokenExchangeErrorInvalidGrant       = "invalid_grant"
+	TokenExchangeErrorInvalidTarget      = "invalid_target"
+	TokenExchangeErrorInvalidScope       = "invalid_scope"
+	TokenExchangeErrorUnauthorizedClient = "unauthorized_client"
+)
+
+// TokenExchangeErrorCode retorna o código de erro do RFC 8693 correspondente a uma troca recusada,
+// ou vazio quando o erro não é uma recusa da troca
+func TokenExchangeErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidTokenExchangeRequest):
+		return TokenExchangeErrorInvalidRequest
+	case errors.Is(err, ErrInvalidSubjectToken), errors.Is(err, ErrInvalidActorToken),
+		errors.Is(err, ErrDelegationDepthExceeded):
+		return TokenExchangeErrorInvalidGrant
+	case errors.Is(err, ErrTokenExchangeTargetNotAllowed):
+		return TokenExchangeErrorInvalidTarget
+	case errors.Is(err, ErrTokenExchangeScopeNotAllowed):
+		return TokenExchangeErrorInvalidScope
+	case errors.Is(err, ErrTokenExchangeActorNotAllowed):
+		return TokenExchangeErrorUnauthorizedClient
+	default:
+		return ""
+	}
+}
+
+// normalizeTokenExchangeValues remove espaços, valores vazios e duplicados, mantendo a ordem
+func normalizeTokenExchangeValues(values []string) []string {
+	normalized := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
+
+// Erros específicos da troca de tokens
+var (
+	ErrTokenExchangePolicyNotFound   = errors.New("política de troca de tokens não encontrada")
+	ErrTokenExchangePolicyConflict   = errors.New("já existe uma política de troca de tokens para a audiência")
+	ErrInvalidTokenExchangePolicy    = errors.New("política de troca de tokens inválida")
+	ErrInvalidTokenExchangeRequest   = errors.New("pedido de troca de tokens inválido")
+	ErrInvalidSubjectToken           = errors.New("token do titular inválido ou expirado")
+	ErrInvalidActorToken             = errors.New("token do serviço que age inválido ou expirado")
+	ErrTokenExchangeTargetNotAllowed = errors.New("audiência sem política de troca de tokens")
+	ErrTokenExchangeActorNotAllowed  = errors.New("serviço não autorizado a agir em nome do titular nesta audiência")
+	ErrTokenExchangeScopeNotAllowed  = errors.New("escopo não permitido na troca de tokens")
+	ErrDelegationDepthExceeded       = errors.New("cadeia de delegação excede o máximo da audiência")
+	ErrInvalidTokenExchangeFilter    = errors.New("filtro do registo de trocas de tokens inválido")
+)
@@ -25,8 +25,10 @@ var (
 	ErrUserAccountLocked    = errors.New("conta de usuário bloqueada")
 	ErrUserAccountSuspended = errors.New("conta de usuário suspensa")
 	ErrUserAccountDisabled  = errors.New("conta de usuário desativada")
+	ErrUserAccountDeprovisioned = errors.New("conta de usuário desprovisionada")
 	ErrInvalidUserStatus    = errors.New("status de usuário inválido")
 	ErrInvalidTenantID      = errors.New("ID do tenant inválido")
+	ErrUserNotFound         = errors.New("usuário não encontrado")
 )
 
 // UserStatus define os possíveis estados de uma conta de usuário
@@ -39,6 +41,8 @@ const (
 	UserStatusSuspended UserStatus = "suspended"
 	UserStatusDisabled  UserStatus = "disabled"
 	UserStatusPending   UserStatus = "pending"
+	UserStatusInvited   UserStatus = "invited"
+	UserStatusDeprovisioned UserStatus = "deprovisioned"
 )
 
 // AuthProvider representa um provedor de autenticação externo
@@ -167,6 +171,9 @@ type User struct {
 	Timezone          string         `json:"timezone"`
 	Metadata          map[string]interface{} `json:"metadata"`
 	Status            UserStatus     `json:"status"`
+	StatusReason      string         `json:"status_reason,omitempty"`
+	StatusChangedAt   *time.Time     `json:"status_changed_at"`
+	InvitationExpiresAt *time.Time   `json:"invitation_expires_at,omitempty"`
 	LoginCount        int            `json:"login_count"`
 	LastLoginAt       *time.Time     `json:"last_login_at"`
 	LastTokenIssuedAt *time.Time     `json:"last_token_issued_at"`
@@ -272,6 +279,9 @@ func (u *User) Activate() error {
 	if u.Status == UserStatusDisabled {
 		return ErrUserAccountDisabled
 	}
+	if u.Status == UserStatusDeprovisioned {
+		return ErrUserAccountDeprovisioned
+	}
 	
 	u.Status = UserStatusActive
 	u.UpdatedAt = time.Now().UTC()
@@ -289,6 +299,9 @@ func (u *User) Unlock() error {
 	if u.Status == UserStatusDisabled {
 		return ErrUserAccountDisabled
 	}
+	if u.Status == UserStatusDeprovisioned {
+		return ErrUserAccountDeprovisioned
+	}
 	
 	u.Status = UserStatusActive
 	u.UpdatedAt = time.Now().UTC()
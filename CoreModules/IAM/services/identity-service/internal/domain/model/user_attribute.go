@@ -0,0 +1,407 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Atributos personalizados dos usuários: cada tenant define os esquemas dos seus atributos
+ * (por exemplo, número de colaborador, centro de custo ou nível de credenciação), com tipo,
+ * obrigatoriedade e restrições. Os valores são validados na escrita contra os esquemas,
+ * entram no contexto das políticas ABAC e, quando o esquema indica uma claim, são emitidos
+ * como claims personalizadas nos tokens.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Limites dos atributos dos usuários
+const (
+	MaxUserAttributeSchemas     = 100
+	MaxUserAttributeEnumValues  = 100
+	MaxUserAttributeStringBytes = 1024
+	UserAttributeDateLayout     = "2006-01-02"
+)
+
+// userAttributeNamePattern valida os nomes dos atributos, usados também no contexto ABAC
+var userAttributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// userAttributeClaimPattern valida os nomes das claims, incluindo as claims com namespace em URI
+var userAttributeClaimPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.:/-]{0,127}$`)
+
+// reservedTokenClaims são as claims registadas (RFC 7519, RFC 8693, OIDC) e as emitidas pelo
+// serviço, que os atributos personalizados não podem substituir
+var reservedTokenClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+	"tid": true, "preferred_username": true, "market": true, "mfa_level": true, "auth_time": true,
+	"sid": true, "client_id": true, "scope": true, "act": true, "azp": true, "nonce": true,
+	"roles": true, "permissions": true, "amr": true, "acr": true, "may_act": true,
+	"email": true, "given_name": true, "family_name": true,
+}
+
+// IsReservedTokenClaim indica se a claim é registada ou emitida pelo próprio serviço
+func IsReservedTokenClaim(name string) bool {
+	return reservedTokenClaims[name]
+}
+
+// UserAttributeType representa o tipo dos valores de um atributo
+type UserAttributeType string
+
+// Tipos dos atributos
+const (
+	UserAttributeString  UserAttributeType = "string"
+	UserAttributeInteger UserAttributeType = "integer"
+	UserAttributeNumber  UserAttributeType = "number"
+	UserAttributeBoolean UserAttributeType = "boolean"
+	UserAttributeDate    UserAttributeType = "date"
+	UserAttributeEnum    UserAttributeType = "enum"
+)
+
+// IsValid indica se o tipo é conhecido
+func (t UserAttributeType) IsValid() bool {
+	switch t {
+	case UserAttributeString, UserAttributeInteger, UserAttributeNumber,
+		UserAttributeBoolean, UserAttributeDate, UserAttributeEnum:
+		return true
+	}
+	return false
+}
+
+// isNumeric indica se o tipo aceita limites mínimo e máximo
+func (t UserAttributeType) isNumeric() bool {
+	return t == UserAttributeInteger || t == UserAttributeNumber
+}
+
+// UserAttributeSchema define um atributo personalizado dos usuários do tenant
+// O nome e o tipo não mudam depois de criado o esquema, para que os valores gravados continuem válidos
+type UserAttributeSchema struct {
+	ID          uuid.UUID         `json:"id"`
+	TenantID    uuid.UUID         `json:"tenant_id"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Type        UserAttributeType `json:"type"`
+	Required    bool              `json:"required"`
+	// Valores aceites pelos atributos do tipo enum
+	EnumValues []string `json:"enum_values,omitempty"`
+	// Expressão regular (RE2) e tamanho máximo, em caracteres, dos atributos do tipo string
+	Pattern   string `json:"pattern,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+	// Limites, inclusivos, dos atributos numéricos
+	MinValue *float64 `json:"min_value,omitempty"`
+	MaxValue *float64 `json:"max_value,omitempty"`
+	// Claim com que o atributo é emitido nos tokens; vazia quando o atributo não entra nos tokens
+	ClaimName string    `json:"claim_name,omitempty"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	UpdatedBy uuid.UUID `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	pattern *regexp.Regexp
+}
+
+// Normalize remove espaços, passa o nome a minúsculas e ordena os valores do enum
+func (s *UserAttributeSchema) Normalize() {
+	s.Name = strings.ToLower(strings.TrimSpace(s.Name))
+	s.DisplayName = strings.TrimSpace(s.DisplayName)
+	s.Description = strings.TrimSpace(s.Description)
+	s.ClaimName = strings.TrimSpace(s.ClaimName)
+	if len(s.EnumValues) > 0 {
+		values := make([]string, 0, len(s.EnumValues))
+		seen := make(map[string]bool, len(s.EnumValues))
+		for _, value := range s.EnumValues {
+			value = strings.TrimSpace(value)
+			if value != "" && !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+		sort.Strings(values)
+		s.EnumValues = values
+	}
+}
+
+// Validate valida o esquema e as restrições permitidas pelo seu tipo
+func (s *UserAttributeSchema) Validate() error {
+	if s.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if !userAttributeNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("%w: nome inválido %q (letras minúsculas, dígitos e _, até 63 caracteres)",
+			ErrInvalidUserAttributeSchema, s.Name)
+	}
+	if len(s.DisplayName) > 255 {
+		return fmt.Errorf("%w: o nome de apresentação tem no máximo 255 caracteres", ErrInvalidUserAttributeSchema)
+	}
+	if !s.Type.IsValid() {
+		return fmt.Errorf("%w: tipo %q desconhecido", ErrInvalidUserAttributeSchema, s.Type)
+	}
+
+	if s.Type == UserAttributeEnum {
+		if len(s.EnumValues) == 0 || len(s.EnumValues) > MaxUserAttributeEnumValues {
+			return fmt.Errorf("%w: o tipo enum exige entre 1 e %d valores", ErrInvalidUserAttributeSchema, MaxUserAttributeEnumValues)
+		}
+	} else if len(s.EnumValues) > 0 {
+		return fmt.Errorf("%w: só o tipo enum aceita valores enumerados", ErrInvalidUserAttributeSchema)
+	}
+
+	if s.Type == UserAttributeString {
+		if s.MaxLength < 0 || s.MaxLength > MaxUserAttributeStringBytes {
+			return fmt.Errorf("%w: o tamanho máximo tem de estar entre 0 e %d", ErrInvalidUserAttributeSchema, MaxUserAttributeStringBytes)
+		}
+		if s.Pattern != "" {
+			if _, err := regexp.Compile(s.Pattern); err != nil {
+				return fmt.Errorf("%w: expressão regular inválida: %v", ErrInvalidUserAttributeSchema, err)
+			}
+		}
+	} else if s.Pattern != "" || s.MaxLength != 0 {
+		return fmt.Errorf("%w: só o tipo string aceita expressão regular e tamanho máximo", ErrInvalidUserAttributeSchema)
+	}
+
+	if s.Type.isNumeric() {
+		if s.MinValue != nil && s.MaxValue != nil && *s.MinValue > *s.MaxValue {
+			return fmt.Errorf("%w: o mínimo é superior ao máximo", ErrInvalidUserAttributeSchema)
+		}
+	} else if s.MinValue != nil || s.MaxValue != nil {
+		return fmt.Errorf("%w: só os tipos integer e number aceitam limites", ErrInvalidUserAttributeSchema)
+	}
+
+	if s.ClaimName != "" {
+		if !userAttributeClaimPattern.MatchString(s.ClaimName) {
+			return fmt.Errorf("%w: claim inválida %q", ErrInvalidUserAttributeSchema, s.ClaimName)
+		}
+		if IsReservedTokenClaim(s.ClaimName) {
+			return fmt.Errorf("%w: a claim %q é reservada", ErrInvalidUserAttributeSchema, s.ClaimName)
+		}
+	}
+	return nil
+}
+
+// NormalizeValue valida um valor do atributo e converte-o para a representação do tipo:
+// string para string, date e enum, int64 para integer, float64 para number e bool para boolean
+// Aceita os valores lidos de JSON, incluindo json.Number
+func (s *UserAttributeSchema) NormalizeValue(value interface{}) (interface{}, error) {
+	switch s.Type {
+	case UserAttributeString:
+		text, ok := value.(string)
+		if !ok {
+			return nil, s.valueError("texto esperado")
+		}
+		if len(text) > MaxUserAttributeStringBytes || (s.MaxLength > 0 && utf8.RuneCountInString(text) > s.MaxLength) {
+			return nil, s.valueError("texto demasiado longo")
+		}
+		if s.Pattern != "" && !s.compiledPattern().MatchString(text) {
+			return nil, s.valueError("texto não corresponde ao formato do atributo")
+		}
+		return text, nil
+
+	case UserAttributeInteger:
+		number, ok := toFloat(value)
+		if !ok || number != math.Trunc(number) || math.Abs(number) > 1<<53 {
+			return nil, s.valueError("inteiro esperado")
+		}
+		if err := s.checkBounds(number); err != nil {
+			return nil, err
+		}
+		return int64(number), nil
+
+	case UserAttributeNumber:
+		number, ok := toFloat(value)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, s.valueError("número esperado")
+		}
+		if err := s.checkBounds(number); err != nil {
+			return nil, err
+		}
+		return number, nil
+
+	case UserAttributeBoolean:
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, s.valueError("booleano esperado")
+		}
+		return flag, nil
+
+	case UserAttributeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, s.valueError("data AAAA-MM-DD esperada")
+		}
+		date, err := time.Parse(UserAttributeDateLayout, text)
+		if err != nil {
+			return nil, s.valueError("data AAAA-MM-DD esperada")
+		}
+		return date.Format(UserAttributeDateLayout), nil
+
+	case UserAttributeEnum:
+		text, ok := value.(string)
+		if !ok {
+			return nil, s.valueError("valor enumerado esperado")
+		}
+		index := sort.SearchStrings(s.EnumValues, text)
+		if index == len(s.EnumValues) || s.EnumValues[index] != text {
+			return nil, s.valueError(fmt.Sprintf("valor %q não permitido", text))
+		}
+		return text, nil
+	}
+	return nil, s.valueError(fmt.Sprintf("tipo %q desconhecido", s.Type))
+}
+
+// checkBounds verifica os limites dos atributos numéricos
+func (s *UserAttributeSchema) checkBounds(number float64) error {
+	if s.MinValue != nil && number < *s.MinValue {
+		return s.valueError(fmt.Sprintf("valor inferior ao mínimo %v", *s.MinValue))
+	}
+	if s.MaxValue != nil && number > *s.MaxValue {
+		return s.valueError(fmt.Sprintf("valor superior ao máximo %v", *s.MaxValue))
+	}
+	return nil
+}
+
+// compiledPattern retorna a expressão regular compilada pelo catálogo ou compila-a a pedido
+// Os esquemas só são gravados depois de validados, pelo que a expressão compila
+func (s *UserAttributeSchema) compiledPattern() *regexp.Regexp {
+	if s.pattern != nil {
+		return s.pattern
+	}
+	return regexp.MustCompile(s.Pattern)
+}
+
+// valueError retorna o erro de um valor recusado pelo esquema
+func (s *UserAttributeSchema) valueError(reason string) error {
+	return fmt.Errorf("%w: atributo %q: %s", ErrInvalidUserAttributes, s.Name, reason)
+}
+
+// toFloat converte os valores numéricos de JSON e de Go para float64
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case json.Number:
+		parsed, err := number.Float64()
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+// UserAttributeCatalog são os esquemas dos atributos de um tenant, indexados pelo nome
+type UserAttributeCatalog struct {
+	schemas map[string]*UserAttributeSchema
+}
+
+// NewUserAttributeCatalog cria o catálogo a partir de cópias dos esquemas do tenant, com as
+// expressões regulares compiladas para que o catálogo possa ser partilhado entre pedidos
+func NewUserAttributeCatalog(schemas []*UserAttributeSchema) *UserAttributeCatalog {
+	catalog := &UserAttributeCatalog{schemas: make(map[string]*UserAttributeSchema, len(schemas))}
+	for _, schema := range schemas {
+		copied := *schema
+		if copied.Pattern != "" {
+			copied.pattern, _ = regexp.Compile(copied.Pattern)
+		}
+		catalog.schemas[copied.Name] = &copied
+	}
+	return catalog
+}
+
+// Validate valida os atributos de um usuário e retorna-os normalizados
+// Os atributos sem esquema são recusados e os obrigatórios têm de estar presentes; um valor
+// nulo equivale a um atributo omitido
+func (c *UserAttributeCatalog) Validate(values map[string]interface{}) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(values))
+	var unknown []string
+	for name, value := range values {
+		schema, ok := c.schemas[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		converted, err := schema.NormalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[name] = converted
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: atributos sem esquema: %s", ErrInvalidUserAttributes, strings.Join(unknown, ", "))
+	}
+
+	var missing []string
+	for name, schema := range c.schemas {
+		if _, ok := normalized[name]; schema.Required && !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: atributos obrigatórios em falta: %s", ErrInvalidUserAttributes, strings.Join(missing, ", "))
+	}
+	return normalized, nil
+}
+
+// Current retorna os atributos gravados que continuam válidos pelos esquemas atuais
+// Os esquemas podem ter sido restringidos depois da gravação; os valores que deixaram de ser
+// válidos não entram nas decisões de autorização nem nos tokens até serem corrigidos
+func (c *UserAttributeCatalog) Current(values map[string]interface{}) map[string]interface{} {
+	current := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		schema, ok := c.schemas[name]
+		if !ok || value == nil {
+			continue
+		}
+		if converted, err := schema.NormalizeValue(value); err == nil {
+			current[name] = converted
+		}
+	}
+	return current
+}
+
+// Claims retorna as claims personalizadas a emitir nos tokens, pelos atributos com claim no esquema
+func (c *UserAttributeCatalog) Claims(values map[string]interface{}) map[string]interface{} {
+	claims := make(map[string]interface{})
+	for name, value := range c.Current(values) {
+		if schema := c.schemas[name]; schema.ClaimName != "" {
+			claims[schema.ClaimName] = value
+		}
+	}
+	return claims
+}
+
+// UserAttributes são os atributos personalizados de um usuário
+type UserAttributes struct {
+	TenantID  uuid.UUID              `json:"tenant_id"`
+	UserID    uuid.UUID              `json:"user_id"`
+	Values    map[string]interface{} `json:"values"`
+	UpdatedBy *uuid.UUID             `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time             `json:"updated_at,omitempty"`
+}
+
+// Erros específicos dos atributos dos usuários
+var (
+	ErrUserAttributeSchemaNotFound = errors.New("esquema de atributo não encontrado")
+	ErrUserAttributeSchemaConflict = errors.New("já existe um atributo com este nome ou claim no tenant")
+	ErrInvalidUserAttributeSchema  = errors.New("esquema de atributo inválido")
+	ErrUserAttributeSchemaLimit    = errors.New("o tenant atingiu o número máximo de esquemas de atributos")
+	ErrInvalidUserAttributes       = errors.New("atributos do usuário inválidos")
+)
@@ -0,0 +1,198 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Ciclo de vida de contas de usuário.
+ * Define os estados explícitos da conta (convidado, ativo, suspenso, desprovisionado),
+ * as transições permitidas entre eles e a política de automação configurável por tenant.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Motivos registados nas transições automáticas do ciclo de vida
+const (
+	UserLifecycleReasonInactivity              = "inactivity"
+	UserLifecycleReasonScheduledDeprovisioning = "scheduled_deprovisioning"
+)
+
+// Erros do ciclo de vida de usuários
+var (
+	ErrInvalidUserTransition       = errors.New("transição de estado de usuário não permitida")
+	ErrUserTransitionReasonMissing = errors.New("motivo obrigatório para a transição de estado do usuário")
+	ErrUserInvitationExpired       = errors.New("convite de usuário expirado")
+	ErrUserLifecycleConflict       = errors.New("estado do usuário alterado concorrentemente")
+	ErrUserLifecyclePolicyNotFound = errors.New("política de ciclo de vida não encontrada para o tenant")
+	ErrInvalidUserLifecyclePolicy  = errors.New("política de ciclo de vida inválida")
+)
+
+// userTransitions define, para cada estado, os estados de destino permitidos
+// Os estados pending e disabled são anteriores ao ciclo de vida explícito e só
+// admitem a migração para os novos estados
+var userTransitions = map[UserStatus][]UserStatus{
+	UserStatusPending:       {UserStatusInvited, UserStatusActive, UserStatusSuspended, UserStatusDeprovisioned},
+	UserStatusInvited:       {UserStatusActive, UserStatusDeprovisioned},
+	UserStatusActive:        {UserStatusLocked, UserStatusSuspended, UserStatusDeprovisioned},
+	UserStatusLocked:        {UserStatusActive, UserStatusSuspended, UserStatusDeprovisioned},
+	UserStatusSuspended:     {UserStatusActive, UserStatusDeprovisioned},
+	UserStatusDisabled:      {UserStatusDeprovisioned},
+	UserStatusDeprovisioned: {},
+}
+
+// CanTransitionTo verifica se a transição para o estado de destino é permitida
+func (s UserStatus) CanTransitionTo(target UserStatus) bool {
+	for _, allowed := range userTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal indica se o estado não admite novas transições
+func (s UserStatus) IsTerminal() bool {
+	return len(userTransitions[s]) == 0
+}
+
+// UserLifecycleTransition registra uma mudança de estado da conta do usuário
+type UserLifecycleTransition struct {
+	ID         uuid.UUID  `json:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FromStatus UserStatus `json:"from_status"`
+	ToStatus   UserStatus `json:"to_status"`
+	Reason     string     `json:"reason,omitempty"`
+	ActorID    *uuid.UUID `json:"actor_id,omitempty"` // Nulo nas transições automáticas
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// Automated indica se a transição foi executada pelo agendador e não por um operador
+func (t *UserLifecycleTransition) Automated() bool {
+	return t.ActorID == nil
+}
+
+// TransitionTo aplica uma transição guardada ao usuário e retorna o registo correspondente
+// Suspensões e desprovisionamentos exigem motivo; a ativação de um convite exige que
+// o convite ainda esteja válido
+func (u *User) TransitionTo(target UserStatus, reason string, actorID *uuid.UUID, at time.Time) (*UserLifecycleTransition, error) {
+	if u.Status == UserStatusDeprovisioned {
+		return nil, ErrUserAccountDeprovisioned
+	}
+	if !u.Status.CanTransitionTo(target) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidUserTransition, u.Status, target)
+	}
+	if reason == "" && (target == UserStatusSuspended || target == UserStatusDeprovisioned) {
+		return nil, ErrUserTransitionReasonMissing
+	}
+	if u.Status == UserStatusInvited && target == UserStatusActive &&
+		u.InvitationExpiresAt != nil && at.After(*u.InvitationExpiresAt) {
+		return nil, ErrUserInvitationExpired
+	}
+
+	transition := &UserLifecycleTransition{
+		ID:         uuid.New(),
+		TenantID:   u.TenantID,
+		UserID:     u.ID,
+		FromStatus: u.Status,
+		ToStatus:   target,
+		Reason:     reason,
+		ActorID:    actorID,
+		OccurredAt: at,
+	}
+
+	u.Status = target
+	u.StatusReason = reason
+	u.StatusChangedAt = &at
+	u.UpdatedAt = at
+
+	switch target {
+	case UserStatusActive:
+		u.InvitationExpiresAt = nil
+	case UserStatusDeprovisioned:
+		u.DeletedAt = &at
+	}
+
+	return transition, nil
+}
+
+// Invite coloca um usuário recém-criado no estado convidado até a data de expiração
+func (u *User) Invite(expiresAt time.Time, actorID *uuid.UUID, at time.Time) (*UserLifecycleTransition, error) {
+	transition, err := u.TransitionTo(UserStatusInvited, "", actorID, at)
+	if err != nil {
+		return nil, err
+	}
+	u.InvitationExpiresAt = &expiresAt
+	return transition, nil
+}
+
+// LastActivityAt retorna o instante mais recente entre a criação, o último login
+// e a última mudança de estado, usado para detectar contas inativas
+func (u *User) LastActivityAt() time.Time {
+	last := u.CreatedAt
+	if u.LastLoginAt != nil && u.LastLoginAt.After(last) {
+		last = *u.LastLoginAt
+	}
+	if u.StatusChangedAt != nil && u.StatusChangedAt.After(last) {
+		last = *u.StatusChangedAt
+	}
+	return last
+}
+
+// UserLifecyclePolicy define a automação do ciclo de vida das contas de um tenant
+// Um prazo igual a zero desativa a automação correspondente
+type UserLifecyclePolicy struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+
+	// Dias sem atividade após os quais uma conta ativa é suspensa
+	SuspendAfterInactivityDays int `json:"suspend_after_inactivity_days"`
+
+	// Dias em suspensão após os quais a conta é desprovisionada
+	DeprovisionAfterSuspensionDays int `json:"deprovision_after_suspension_days"`
+
+	// Dias de validade de um convite
+	InvitationTTLDays int `json:"invitation_ttl_days"`
+
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+}
+
+// Validate verifica a consistência da política
+func (p *UserLifecyclePolicy) Validate() error {
+	if p.TenantID == uuid.Nil {
+		return ErrInvalidTenantID
+	}
+	if p.SuspendAfterInactivityDays < 0 || p.DeprovisionAfterSuspensionDays < 0 || p.InvitationTTLDays < 0 {
+		return fmt.Errorf("%w: prazos não podem ser negativos", ErrInvalidUserLifecyclePolicy)
+	}
+	return nil
+}
+
+// InactivityCutoff retorna o instante antes do qual a última atividade leva à suspensão
+// O segundo valor é falso quando a suspensão automática está desativada
+func (p *UserLifecyclePolicy) InactivityCutoff(now time.Time) (time.Time, bool) {
+	if p.SuspendAfterInactivityDays == 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -p.SuspendAfterInactivityDays), true
+}
+
+// DeprovisionCutoff retorna o instante antes do qual uma suspensão leva ao desprovisionamento
+// O segundo valor é falso quando o desprovisionamento agendado está desativado
+func (p *UserLifecyclePolicy) DeprovisionCutoff(now time.Time) (time.Time, bool) {
+	if p.DeprovisionAfterSuspensionDays == 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -p.DeprovisionAfterSuspensionDays), true
+}
+
+// InvitationExpiry retorna a data de expiração de um convite emitido no instante indicado
+func (p *UserLifecyclePolicy) InvitationExpiry(at time.Time) time.Time {
+	return at.AddDate(0, 0, p.InvitationTTLDays)
+}
@@ -0,0 +1,52 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para os pedidos de acesso em autoatendimento.
+ * Define a persistência dos pedidos, dos aprovadores e das permissões concedidas diretamente.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// AccessRequestRepository define a interface para persistência de pedidos de acesso
+type AccessRequestRepository interface {
+	// Create grava um novo pedido pendente
+	// Retorna model.ErrDuplicateAccessRequest se o requerente já tiver um pedido pendente para o mesmo destino
+	Create(ctx context.Context, req *model.AccessRequest) error
+
+	// Get recupera um pedido pelo ID
+	// Retorna model.ErrAccessRequestNotFound quando o pedido não existe no tenant
+	Get(ctx context.Context, tenantID, requestID uuid.UUID) (*model.AccessRequest, error)
+
+	// List recupera os pedidos do tenant que satisfazem o filtro, do mais antigo para o mais recente
+	List(ctx context.Context, tenantID uuid.UUID, filter model.AccessRequestFilter) ([]*model.AccessRequest, error)
+
+	// SaveDecision grava o estado e a decisão do pedido
+	// Retorna model.ErrAccessRequestConflict se o estado armazenado já não for o esperado
+	SaveDecision(ctx context.Context, req *model.AccessRequest, expected model.AccessRequestStatus) error
+
+	// PermissionExists verifica se a permissão existe e está ativa no tenant
+	PermissionExists(ctx context.Context, tenantID, permissionID uuid.UUID) (bool, error)
+
+	// GrantPermission concede uma permissão diretamente ao usuário, substituindo uma concessão anterior
+	GrantPermission(ctx context.Context, tenantID, userID, permissionID uuid.UUID, expiresAt *time.Time, grantedBy, requestID uuid.UUID) error
+
+	// ListApprovers recupera os aprovadores do tenant, ou apenas os do usuário quando indicado
+	ListApprovers(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.AccessRequestApprover, error)
+
+	// SaveApprover grava um aprovador
+	SaveApprover(ctx context.Context, approver *model.AccessRequestApprover) error
+
+	// DeleteApprover remove um aprovador
+	// Retorna model.ErrAccessApproverNotFound quando o aprovador não existe no tenant
+	DeleteApprover(ctx context.Context, tenantID, approverID uuid.UUID) error
+}
@@ -0,0 +1,62 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a ligação de contas entre fornecedores de identidade.
+ * Define a persistência dos candidatos à ligação, das identidades ligadas aos usuários
+ * e do registo de auditoria de cada operação.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// AccountLinkRepository define a interface para persistência da ligação de contas
+type AccountLinkRepository interface {
+	// FindUserByVerifiedEmail recupera o usuário do tenant com o email indicado, desde que verificado
+	// Retorna model.ErrUserNotFound quando não existe conta com o email verificado
+	FindUserByVerifiedEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error)
+
+	// ListFederatedIdentities recupera as identidades federadas do usuário
+	ListFederatedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.FederatedIdentity, error)
+
+	// ListLinkedIdentities recupera os métodos de login do usuário: a senha local, quando existe,
+	// seguida das identidades federadas com o nome do fornecedor
+	ListLinkedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LinkedIdentity, error)
+
+	// SaveCandidate grava um candidato à ligação e o respetivo evento na mesma transação
+	// Um candidato pendente para o mesmo NameID do fornecedor passa a expirado
+	SaveCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error
+
+	// GetCandidate recupera um candidato à ligação do tenant
+	// Retorna model.ErrAccountLinkCandidateNotFound quando o candidato não existe
+	GetCandidate(ctx context.Context, tenantID, candidateID uuid.UUID) (*model.AccountLinkCandidate, error)
+
+	// ListOpenCandidates recupera os candidatos pendentes e não expirados do usuário, mais recentes primeiro
+	ListOpenCandidates(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.AccountLinkCandidate, error)
+
+	// LinkCandidate marca o candidato como ligado, cria a identidade federada e grava o evento numa única transação
+	// Retorna model.ErrAccountLinkCandidateClosed quando o candidato já não está pendente e
+	// model.ErrIdentityAlreadyLinked quando o NameID foi ligado entretanto a outro usuário
+	LinkCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, identity *model.FederatedIdentity, event *model.AccountLinkEvent) error
+
+	// DismissCandidate marca o candidato como recusado e grava o evento na mesma transação
+	// Retorna model.ErrAccountLinkCandidateClosed quando o candidato já não está pendente
+	DismissCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error
+
+	// UnlinkIdentity remove a identidade federada do usuário e grava o evento na mesma transação,
+	// completado com o fornecedor e o NameID, retornando a identidade removida
+	// Retorna model.ErrLinkedIdentityNotFound quando a identidade não pertence ao usuário e
+	// model.ErrLastLinkedIdentity quando o usuário ficaria sem senha e sem outra identidade federada
+	UnlinkIdentity(ctx context.Context, tenantID, userID, identityID uuid.UUID, event *model.AccountLinkEvent) (*model.FederatedIdentity, error)
+
+	// ListEvents recupera o registo de auditoria do tenant, mais recentes primeiro
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.AccountLinkEventFilter) ([]*model.AccountLinkEvent, error)
+}
@@ -0,0 +1,24 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a consulta dos registos de auditoria.
+ * Os registos são gravados pelos triggers da base de dados; o repositório apenas os lê.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// AuditLogRepository define a interface de leitura dos registos de auditoria
+type AuditLogRepository interface {
+	// Search recupera até filter.Limit registos do tenant que satisfazem o filtro, anteriores ao
+	// cursor, ordenados por (created_at, id) decrescente
+	Search(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) ([]*model.AuditLogEntry, error)
+}
@@ -0,0 +1,51 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as operações em massa sobre usuários.
+ * Define a persistência das tarefas e dos seus itens e a gravação atómica dos resultados
+ * de cada lote com o registo de auditoria de cada usuário afetado.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// BulkUserJobRepository define a interface para persistência das tarefas de operação em massa
+type BulkUserJobRepository interface {
+	// Create grava uma nova tarefa e os seus itens numa transação
+	Create(ctx context.Context, job *model.BulkUserJob, items []*model.BulkUserJobItem) error
+
+	// Get recupera uma tarefa pelo ID
+	// Retorna model.ErrBulkUserJobNotFound quando a tarefa não existe no tenant
+	Get(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error)
+
+	// List recupera as tarefas do tenant, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.BulkUserJob, error)
+
+	// ListActive recupera as tarefas pendentes ou em execução de todos os tenants, da mais antiga para a mais recente
+	ListActive(ctx context.Context, limit int) ([]*model.BulkUserJob, error)
+
+	// ListItems recupera até limit itens da tarefa com linha posterior a afterLine, ordenados pela linha
+	// Um estado vazio não filtra os itens
+	ListItems(ctx context.Context, tenantID, jobID uuid.UUID, status model.BulkUserItemStatus, afterLine, limit int) ([]*model.BulkUserJobItem, error)
+
+	// Save grava o estado e os contadores da tarefa e incrementa a sua versão
+	// Retorna model.ErrBulkUserJobConflict se a versão armazenada já não for a esperada
+	Save(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error
+
+	// SaveResults grava numa transação o resultado dos itens processados, um registo de auditoria
+	// por usuário afetado e os contadores da tarefa, incrementando a sua versão
+	// Retorna model.ErrBulkUserJobConflict se a versão armazenada já não for a esperada
+	SaveResults(ctx context.Context, job *model.BulkUserJob, items []*model.BulkUserJobItem, expectedVersion int64) error
+
+	// Requeue grava a tarefa retomada e devolve à fila os seus itens falhados numa transação
+	// Retorna model.ErrBulkUserJobConflict se a versão armazenada já não for a esperada
+	Requeue(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error
+}
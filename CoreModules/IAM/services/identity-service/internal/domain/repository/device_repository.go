@@ -0,0 +1,53 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para o registo de dispositivos dos usuários.
+ * Define a persistência da política de dispositivos de cada tenant e dos dispositivos
+ * vistos nos logins de cada usuário.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// DeviceRepository define a interface para persistência do registo de dispositivos
+type DeviceRepository interface {
+	// GetPolicy recupera a política de dispositivos do tenant, ou nil se não existir
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.DevicePolicy, error)
+
+	// SavePolicy grava a política de dispositivos do tenant
+	SavePolicy(ctx context.Context, policy *model.DevicePolicy) error
+
+	// RecordSighting regista um login a partir do dispositivo, identificado pelo usuário e pela
+	// impressão. Um dispositivo ativo já conhecido é atualizado e os seus dados gravados são
+	// escritos em device; um dispositivo desconhecido ou revogado é registado como novo, sem
+	// confiança, e registered é verdadeiro
+	RecordSighting(ctx context.Context, device *model.Device) (registered bool, err error)
+
+	// FindByFingerprint recupera o dispositivo do usuário com a impressão indicada
+	// Retorna model.ErrDeviceNotFound quando o usuário nunca usou o dispositivo
+	FindByFingerprint(ctx context.Context, tenantID, userID uuid.UUID, fingerprint string) (*model.Device, error)
+
+	// ListAccountsByReuseKey recupera os usuários distintos com um dispositivo ativo com a chave de
+	// partilha indicada, visto desde since
+	ListAccountsByReuseKey(ctx context.Context, tenantID uuid.UUID, reuseKey string, since time.Time) ([]uuid.UUID, error)
+
+	// List recupera os dispositivos do usuário, do usado mais recentemente para o mais antigo
+	List(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.Device, error)
+
+	// Get recupera um dispositivo do usuário
+	// Retorna model.ErrDeviceNotFound quando o dispositivo não existe ou é de outro usuário
+	Get(ctx context.Context, tenantID, userID, deviceID uuid.UUID) (*model.Device, error)
+
+	// Update grava o nome, a confiança, o estado e a sinalização de partilha do dispositivo
+	// Retorna model.ErrDeviceNotFound quando o dispositivo não existe
+	Update(ctx context.Context, device *model.Device) error
+}
@@ -0,0 +1,62 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as contas de emergência (break-glass).
+ * Define a persistência das contas pré-provisionadas, das suas ativações e do registo
+ * encadeado das ações feitas durante cada ativação.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// EmergencyAccessRepository define a interface para persistência das contas de emergência
+type EmergencyAccessRepository interface {
+	// CreateAccount grava uma nova conta de emergência
+	// Retorna model.ErrEmergencyAccountConflict quando o usuário ou o nome já pertencem a uma conta do tenant
+	CreateAccount(ctx context.Context, account *model.EmergencyAccount) error
+
+	// GetAccount recupera uma conta de emergência do tenant
+	// Retorna model.ErrEmergencyAccountNotFound quando a conta não existe
+	GetAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.EmergencyAccount, error)
+
+	// ListAccounts recupera as contas de emergência do tenant, ordenadas pelo nome
+	ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]*model.EmergencyAccount, error)
+
+	// CreateActivation grava um novo pedido de ativação
+	// Retorna model.ErrEmergencyActivationOpen quando a conta já tem uma ativação pendente ou em vigor
+	CreateActivation(ctx context.Context, activation *model.EmergencyActivation) error
+
+	// GetActivation recupera uma ativação do tenant
+	// Retorna model.ErrEmergencyActivationNotFound quando a ativação não existe
+	GetActivation(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyActivation, error)
+
+	// ListActivations recupera as ativações do tenant, mais recentes primeiro
+	// Com status preenchido, retorna apenas as ativações nesse estado
+	ListActivations(ctx context.Context, tenantID uuid.UUID, status model.EmergencyActivationStatus) ([]*model.EmergencyActivation, error)
+
+	// UpdateActivation grava a transição de estado de uma ativação que estava no estado from
+	// Retorna model.ErrEmergencyActivationState quando o estado foi alterado entretanto
+	UpdateActivation(ctx context.Context, activation *model.EmergencyActivation, from model.EmergencyActivationStatus) error
+
+	// ListDueActivations recupera, de todos os tenants, as ativações em vigor que expiraram até now
+	// e os pedidos pendentes feitos até pendingBefore
+	ListDueActivations(ctx context.Context, now, pendingBefore time.Time, limit int) ([]*model.EmergencyActivation, error)
+
+	// AppendAction acrescenta uma ação ao registo da ativação, ligando-a ao último registo com
+	// action.Chain na mesma transação
+	// Retorna model.ErrEmergencyActivationState quando a ativação já não está em vigor
+	AppendAction(ctx context.Context, action *model.EmergencyAction) error
+
+	// ListActions recupera as ações de uma ativação com sequência superior a afterSequence,
+	// por ordem crescente da sequência
+	ListActions(ctx context.Context, tenantID, activationID uuid.UUID, afterSequence int64, limit int) ([]*model.EmergencyAction, error)
+}
@@ -0,0 +1,48 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a aceitação de documentos legais.
+ * Define a persistência das versões publicadas dos termos de serviço, das políticas de
+ * privacidade e dos consentimentos, e dos registos de aceitação de cada usuário.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// LegalConsentRepository define a interface para persistência dos documentos legais e das aceitações
+type LegalConsentRepository interface {
+	// CreateDocument grava uma nova versão de um documento legal
+	// Retorna model.ErrLegalDocumentVersionExists quando o tenant já publicou a versão para o tipo
+	CreateDocument(ctx context.Context, document *model.LegalDocument) error
+
+	// GetDocument recupera uma versão de um documento legal do tenant
+	// Retorna model.ErrLegalDocumentNotFound quando o documento não existe
+	GetDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalDocument, error)
+
+	// ListDocuments recupera as versões publicadas pelo tenant, mais recentes primeiro
+	ListDocuments(ctx context.Context, tenantID uuid.UUID) ([]*model.LegalDocument, error)
+
+	// ListCurrentDocuments recupera a versão em vigor de cada tipo de documento do tenant
+	ListCurrentDocuments(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]*model.LegalDocument, error)
+
+	// ListUnacceptedDocuments recupera as versões em vigor que o usuário ainda não aceitou
+	ListUnacceptedDocuments(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.LegalDocument, error)
+
+	// SaveAcceptance grava a aceitação; quando o usuário já aceitou a versão, retorna a aceitação existente
+	SaveAcceptance(ctx context.Context, acceptance *model.LegalAcceptance) (*model.LegalAcceptance, error)
+
+	// ListAcceptances recupera os registos de aceitação do tenant, mais recentes primeiro
+	ListAcceptances(ctx context.Context, tenantID uuid.UUID, filter model.LegalAcceptanceFilter) ([]*model.LegalAcceptance, error)
+
+	// CountAcceptances conta os usuários ativos do tenant e os que aceitaram a versão do documento
+	CountAcceptances(ctx context.Context, tenantID, documentID uuid.UUID) (activeUsers, accepted int, err error)
+}
@@ -0,0 +1,68 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as notificações de logins suspeitos.
+ * Define a persistência da configuração e dos modelos de mensagem de cada tenant,
+ * dos contextos de login conhecidos de cada usuário e das notificações enviadas.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// LoginNotificationRepository define a interface para persistência das notificações de login
+type LoginNotificationRepository interface {
+	// GetSettings recupera a configuração das notificações do tenant, ou nil se não existir
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.LoginNotificationSettings, error)
+
+	// SaveSettings grava a configuração das notificações do tenant
+	SaveSettings(ctx context.Context, settings *model.LoginNotificationSettings) error
+
+	// ListTemplates recupera os modelos de mensagem do tenant
+	ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]*model.LoginNotificationTemplate, error)
+
+	// SaveTemplate grava o modelo da mensagem, canal, mercado e idioma, substituindo o existente
+	// O identificador do modelo substituído é mantido e escrito em template.ID
+	SaveTemplate(ctx context.Context, template *model.LoginNotificationTemplate) error
+
+	// DeleteTemplate remove um modelo do tenant
+	// Retorna model.ErrLoginNotificationTemplateNotFound quando o modelo não existe
+	DeleteTemplate(ctx context.Context, tenantID, templateID uuid.UUID) error
+
+	// GetRecipient recupera os contactos e o idioma do usuário
+	// Retorna model.ErrUserNotFound quando o usuário não existe no tenant
+	GetRecipient(ctx context.Context, tenantID, userID uuid.UUID) (*model.LoginNotificationRecipient, error)
+
+	// TouchLoginContexts regista os contextos do login e retorna os tipos de contexto que não
+	// foram vistos desde staleBefore. firstLogin indica que o usuário não tinha contextos conhecidos.
+	// Os contextos não vistos desde staleBefore são esquecidos
+	TouchLoginContexts(ctx context.Context, tenantID, userID uuid.UUID, contexts []model.LoginContextFingerprint, seenAt, staleBefore time.Time) (unknown []model.LoginContextKind, firstLogin bool, err error)
+
+	// CreateNotification grava uma notificação antes do envio, para que o link "não fui eu" já seja válido
+	CreateNotification(ctx context.Context, notification *model.LoginNotification) error
+
+	// SaveDeliveries grava o resultado das entregas de uma notificação
+	SaveDeliveries(ctx context.Context, tenantID, notificationID uuid.UUID, deliveries []model.LoginNotificationDelivery) error
+
+	// GetNotification recupera uma notificação do tenant
+	// Retorna model.ErrLoginNotificationNotFound quando a notificação não existe
+	GetNotification(ctx context.Context, tenantID, notificationID uuid.UUID) (*model.LoginNotification, error)
+
+	// ListNotifications recupera as notificações mais recentes do usuário
+	ListNotifications(ctx context.Context, tenantID, userID uuid.UUID, limit int) ([]*model.LoginNotification, error)
+
+	// RecordNotMeResponse regista a resposta "não fui eu" atomicamente: marca a notificação como
+	// respondida, termina as sessões ativas do usuário, grava o pedido de redefinição da credencial,
+	// marca a senha atual como expirada e esquece os contextos do login notificado.
+	// Retorna o número de sessões terminadas, ou model.ErrInvalidLoginResponseToken quando a
+	// notificação já foi respondida ou expirou
+	RecordNotMeResponse(ctx context.Context, notification *model.LoginNotification, reset *model.CredentialResetToken, respondedAt time.Time) (int, error)
+}
@@ -0,0 +1,58 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as políticas de rede dos tenants.
+ * Define a persistência das listas de endereços permitidos e bloqueados, dos acessos
+ * de emergência (break-glass) e do registo de auditoria do seu uso.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// NetworkPolicyRepository define a interface para persistência das políticas de rede
+type NetworkPolicyRepository interface {
+	// ListPolicies recupera todas as políticas do tenant, ativas ou não
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.NetworkPolicy, error)
+
+	// GetPolicy recupera uma política do tenant
+	// Retorna model.ErrNetworkPolicyNotFound quando a política não existe
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.NetworkPolicy, error)
+
+	// CreatePolicy grava uma nova política
+	// Retorna model.ErrNetworkPolicyConflict quando o nome já existe no tenant
+	CreatePolicy(ctx context.Context, policy *model.NetworkPolicy) error
+
+	// UpdatePolicy altera uma política existente
+	// Retorna model.ErrNetworkPolicyNotFound quando a política não existe
+	UpdatePolicy(ctx context.Context, policy *model.NetworkPolicy) error
+
+	// DeletePolicy remove uma política do tenant
+	// Retorna model.ErrNetworkPolicyNotFound quando a política não existe
+	DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error
+
+	// CreateBreakGlass grava um novo acesso de emergência
+	CreateBreakGlass(ctx context.Context, override *model.BreakGlassOverride) error
+
+	// RevokeBreakGlass revoga um acesso de emergência em vigor
+	// Retorna model.ErrBreakGlassNotFound quando o acesso não existe ou já foi revogado
+	RevokeBreakGlass(ctx context.Context, tenantID, overrideID, revokedBy uuid.UUID, revokedAt time.Time) error
+
+	// ListBreakGlass recupera os acessos de emergência do tenant, mais recentes primeiro
+	// Com activeAt preenchido, retorna apenas os acessos em vigor nesse instante
+	ListBreakGlass(ctx context.Context, tenantID uuid.UUID, activeAt *time.Time) ([]*model.BreakGlassOverride, error)
+
+	// RecordBreakGlassUse grava o uso de um acesso de emergência
+	RecordBreakGlassUse(ctx context.Context, use *model.BreakGlassUse) error
+
+	// ListBreakGlassUses recupera os usos de um acesso de emergência, mais recentes primeiro
+	ListBreakGlassUses(ctx context.Context, tenantID, overrideID uuid.UUID, limit int) ([]*model.BreakGlassUse, error)
+}
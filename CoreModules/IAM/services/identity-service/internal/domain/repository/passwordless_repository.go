@@ -0,0 +1,52 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a autenticação sem senha.
+ * Define a persistência da configuração dos tenants e dos desafios de uso único
+ * (links mágicos e códigos enviados por email).
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// PasswordlessRepository define a interface para persistência da autenticação sem senha
+type PasswordlessRepository interface {
+	// GetSettings recupera a configuração do tenant
+	// Retorna nil, nil quando o tenant ainda não configurou a autenticação sem senha
+	GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.PasswordlessSettings, error)
+
+	// SaveSettings grava a configuração do tenant, substituindo a anterior
+	SaveSettings(ctx context.Context, settings *model.PasswordlessSettings) error
+
+	// FindUserByEmail recupera o usuário do tenant com o email indicado (sem distinção de maiúsculas)
+	// Retorna model.ErrUserNotFound quando não existe conta com o email
+	FindUserByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error)
+
+	// GetUser recupera um usuário do tenant
+	GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error)
+
+	// CountRecentChallenges conta os desafios do tenant criados desde o instante indicado
+	// para o email e para o endereço IP, usados para limitar os pedidos
+	CountRecentChallenges(ctx context.Context, tenantID uuid.UUID, email, ipAddress string, since time.Time) (byEmail, byIP int, err error)
+
+	// CreateChallenge grava um novo desafio
+	CreateChallenge(ctx context.Context, challenge *model.PasswordlessChallenge) error
+
+	// ClaimAttempt regista uma tentativa de verificação do desafio e retorna-o
+	// Retorna model.ErrPasswordlessChallengeNotFound se o desafio não existir, tiver expirado,
+	// já tiver sido consumido ou tiver esgotado as tentativas
+	ClaimAttempt(ctx context.Context, challengeID uuid.UUID, now time.Time) (*model.PasswordlessChallenge, error)
+
+	// ConsumeChallenge marca o desafio como consumido e regista o login do usuário numa única transação
+	// Retorna model.ErrPasswordlessChallengeNotFound se o desafio já tiver sido consumido ou tiver expirado
+	ConsumeChallenge(ctx context.Context, challengeID uuid.UUID, now time.Time) error
+}
@@ -0,0 +1,41 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para os limites de permissões.
+ * Define a persistência do conjunto máximo de permissões de cada função
+ * e de cada administrador delegado do tenant.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// PermissionBoundaryRepository define a interface para persistência dos limites de permissões
+type PermissionBoundaryRepository interface {
+	// List recupera os limites do tenant, com o código atual das funções titulares, ordenados pelo nome
+	List(ctx context.Context, tenantID uuid.UUID) ([]*model.PermissionBoundary, error)
+
+	// Get recupera um limite do tenant
+	// Retorna model.ErrPermissionBoundaryNotFound quando o limite não existe
+	Get(ctx context.Context, tenantID, boundaryID uuid.UUID) (*model.PermissionBoundary, error)
+
+	// Create grava um novo limite
+	// Retorna model.ErrPermissionBoundarySubjectNotFound quando a função ou o usuário titular não existe
+	// no tenant e model.ErrPermissionBoundaryConflict quando o titular já tem um limite
+	Create(ctx context.Context, boundary *model.PermissionBoundary) error
+
+	// Update altera o nome, a descrição e os padrões de um limite
+	// Retorna model.ErrPermissionBoundaryNotFound quando o limite não existe
+	Update(ctx context.Context, boundary *model.PermissionBoundary) error
+
+	// Delete remove um limite
+	// Retorna model.ErrPermissionBoundaryNotFound quando o limite não existe
+	Delete(ctx context.Context, tenantID, boundaryID uuid.UUID) error
+}
@@ -0,0 +1,26 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a auditoria das decisões de autorização.
+ * Define a gravação em lote das decisões do PDP e a sua consulta por usuário, recurso e período.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// PermissionDecisionRepository define a interface para persistência das decisões de autorização
+type PermissionDecisionRepository interface {
+	// AppendBatch grava um lote de decisões, possivelmente de vários tenants, numa única operação
+	AppendBatch(ctx context.Context, decisions []*model.PermissionDecision) error
+
+	// List recupera as decisões do tenant que satisfazem o filtro, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID uuid.UUID, filter model.PermissionDecisionFilter) ([]*model.PermissionDecision, error)
+}
@@ -10,21 +10,27 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 
 	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
 )
 
+// ErrPermissionNotFound é retornado quando a permissão não existe no tenant
+var ErrPermissionNotFound = errors.New("permissão não encontrada")
+
 // PermissionRepository define a interface para operações de persistência de permissões
 type PermissionRepository interface {
 	// Create cria uma nova permissão no banco de dados
 	Create(ctx context.Context, permission *model.Permission) error
 
 	// GetByID recupera uma permissão pelo seu ID
+	// Retorna ErrPermissionNotFound quando a permissão não existe no tenant
 	GetByID(ctx context.Context, tenantID, permissionID uuid.UUID) (*model.Permission, error)
 
 	// GetByCode recupera uma permissão pelo seu código
+	// Retorna ErrPermissionNotFound quando a permissão não existe no tenant
 	GetByCode(ctx context.Context, tenantID uuid.UUID, code string) (*model.Permission, error)
 
 	// List lista permissões com filtros e paginação
@@ -0,0 +1,58 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as campanhas de recertificação de acessos.
+ * Define a persistência das campanhas e dos itens de revisão gerados a partir do seu âmbito.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// RecertificationRepository define a interface para persistência das campanhas de recertificação
+type RecertificationRepository interface {
+	// CreateCampaign grava uma nova campanha e os seus itens na mesma transação
+	CreateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, items []*model.RecertificationItem) error
+
+	// GetCampaign recupera uma campanha do tenant
+	// Retorna model.ErrRecertificationCampaignNotFound quando a campanha não existe
+	GetCampaign(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationCampaign, error)
+
+	// ListCampaigns recupera as campanhas do tenant, mais recentes primeiro
+	// Com status preenchido, retorna apenas as campanhas nesse estado
+	ListCampaigns(ctx context.Context, tenantID uuid.UUID, status model.RecertificationCampaignStatus) ([]*model.RecertificationCampaign, error)
+
+	// UpdateCampaign grava a campanha que estava no estado from
+	// Retorna model.ErrRecertificationCampaignState quando o estado foi alterado entretanto
+	UpdateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, from model.RecertificationCampaignStatus) error
+
+	// ListDueCampaigns recupera, de todos os tenants, as campanhas ativas cujo prazo terminou até now
+	// ou que chegaram à data de escalamento sem terem sido escaladas
+	ListDueCampaigns(ctx context.Context, now time.Time, limit int) ([]*model.RecertificationCampaign, error)
+
+	// GetItem recupera um item de recertificação do tenant
+	// Retorna model.ErrRecertificationItemNotFound quando o item não existe
+	GetItem(ctx context.Context, tenantID, itemID uuid.UUID) (*model.RecertificationItem, error)
+
+	// ListItems recupera os itens do tenant que satisfazem o filtro, ordenados pela função e pelo usuário
+	ListItems(ctx context.Context, tenantID uuid.UUID, filter model.RecertificationItemFilter) ([]*model.RecertificationItem, error)
+
+	// UpdateItem grava o item que estava no estado from
+	// Retorna model.ErrRecertificationItemDecided quando o item foi decidido entretanto
+	UpdateItem(ctx context.Context, item *model.RecertificationItem, from model.RecertificationItemStatus) error
+
+	// CountPendingItems conta os itens da campanha que aguardam decisão
+	CountPendingItems(ctx context.Context, tenantID, campaignID uuid.UUID) (int, error)
+
+	// ListPendingRevocations recupera, de todos os tenants, os itens revogados cuja atribuição
+	// ainda não foi retirada
+	ListPendingRevocations(ctx context.Context, limit int) ([]*model.RecertificationItem, error)
+}
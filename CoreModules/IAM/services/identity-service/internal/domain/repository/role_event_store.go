@@ -0,0 +1,39 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para o histórico event-sourced de funções (roles).
+ * Define operações append-only sobre os eventos e o armazenamento de snapshots.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// RoleEventStore define a interface para persistência do histórico imutável de funções
+type RoleEventStore interface {
+	// Append acrescenta eventos ao histórico da função
+	// Retorna model.ErrRoleHistoryVersionConflict se a versão de algum evento já existir
+	Append(ctx context.Context, events ...*model.RoleHistoryEvent) error
+
+	// LastVersion retorna a versão do último evento da função (0 quando não há eventos)
+	LastVersion(ctx context.Context, tenantID, roleID uuid.UUID) (int64, error)
+
+	// LoadEvents recupera, em ordem de versão, os eventos posteriores a afterVersion
+	// ocorridos até o instante indicado (inclusive)
+	LoadEvents(ctx context.Context, tenantID, roleID uuid.UUID, afterVersion int64, until time.Time) ([]*model.RoleHistoryEvent, error)
+
+	// SaveSnapshot armazena o estado materializado da função numa versão
+	SaveSnapshot(ctx context.Context, snapshot *model.RoleSnapshot) error
+
+	// LatestSnapshot recupera o snapshot mais recente tirado até o instante indicado
+	// Retorna nil, nil quando não existe snapshot
+	LatestSnapshot(ctx context.Context, tenantID, roleID uuid.UUID, until time.Time) (*model.RoleSnapshot, error)
+}
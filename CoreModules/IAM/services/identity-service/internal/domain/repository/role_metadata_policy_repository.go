@@ -0,0 +1,27 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as políticas dos metadados das funções.
+ * As políticas são lidas do catálogo de permissões; o repositório indica ainda quais
+ * dessas permissões um usuário detém, diretamente ou através das suas funções.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// RoleMetadataPolicyRepository define a interface para consultar as políticas dos metadados das funções
+type RoleMetadataPolicyRepository interface {
+	// ListPolicyPermissions recupera os códigos das permissões ativas do catálogo do tenant
+	// que protegem chaves dos metadados (prefixo "roles:metadata.")
+	ListPolicyPermissions(ctx context.Context, tenantID uuid.UUID) ([]string, error)
+
+	// ListGrantedPermissions retorna os códigos indicados que o usuário detém, por atribuição direta
+	// em vigor ou através das suas funções e das funções ascendentes destas
+	ListGrantedPermissions(ctx context.Context, tenantID, userID uuid.UUID, permissionCodes []string) ([]string, error)
+}
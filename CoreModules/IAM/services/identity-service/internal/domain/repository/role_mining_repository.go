@@ -0,0 +1,42 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a mineração de funções.
+ * Define a leitura das permissões atribuídas diretamente aos usuários e a persistência
+ * das sugestões de função e da sua revisão.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// RoleMiningRepository define a interface para persistência da mineração de funções
+type RoleMiningRepository interface {
+	// ListTenantsWithDirectPermissions recupera os tenants com permissões atribuídas diretamente a usuários
+	ListTenantsWithDirectPermissions(ctx context.Context) ([]uuid.UUID, error)
+
+	// ListDirectAssignments recupera as permissões diretas em vigor dos usuários ativos do tenant
+	ListDirectAssignments(ctx context.Context, tenantID uuid.UUID) ([]*model.DirectPermissionAssignment, error)
+
+	// ReplacePendingSuggestions substitui as sugestões pendentes do tenant, mantendo as já revistas
+	ReplacePendingSuggestions(ctx context.Context, tenantID uuid.UUID, suggestions []*model.RoleSuggestion) error
+
+	// ListSuggestions recupera as sugestões do tenant no estado indicado (todas quando vazio),
+	// das que substituem mais atribuições diretas para as que substituem menos
+	ListSuggestions(ctx context.Context, tenantID uuid.UUID, status string) ([]*model.RoleSuggestion, error)
+
+	// GetSuggestion recupera uma sugestão do tenant
+	// Retorna model.ErrRoleSuggestionNotFound quando a sugestão não existe
+	GetSuggestion(ctx context.Context, tenantID, suggestionID uuid.UUID) (*model.RoleSuggestion, error)
+
+	// SaveReview grava a revisão de uma sugestão ainda pendente
+	// Retorna model.ErrRoleSuggestionNotPending se a sugestão já tiver sido revista
+	SaveReview(ctx context.Context, suggestion *model.RoleSuggestion) error
+}
@@ -10,21 +10,27 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 
 	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
 )
 
+// ErrRoleNotFound é retornado quando a função não existe no tenant
+var ErrRoleNotFound = errors.New("função não encontrada")
+
 // RoleRepository define a interface para operações de persistência de funções
 type RoleRepository interface {
 	// Create cria uma nova função no banco de dados
 	Create(ctx context.Context, role *model.Role) error
 
 	// GetByID recupera uma função pelo seu ID
+	// Retorna ErrRoleNotFound quando a função não existe no tenant
 	GetByID(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error)
 
 	// GetByCode recupera uma função pelo seu código
+	// Retorna ErrRoleNotFound quando a função não existe no tenant
 	GetByCode(ctx context.Context, tenantID uuid.UUID, code string) (*model.Role, error)
 
 	// List lista funções com filtros e paginação
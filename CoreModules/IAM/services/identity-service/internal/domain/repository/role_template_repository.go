@@ -0,0 +1,51 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a biblioteca de modelos de função.
+ * Define a persistência do catálogo global, das instâncias por tenant e a resolução
+ * dos códigos de permissão dos modelos nas permissões de cada tenant.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// RoleTemplateRepository define a interface para persistência de modelos de função
+type RoleTemplateRepository interface {
+	// CreateTemplate grava um novo modelo no catálogo
+	// Retorna model.ErrRoleTemplateCodeAlreadyExists se o código já existir no mesmo mercado
+	CreateTemplate(ctx context.Context, template *model.RoleTemplate) error
+
+	// GetTemplate recupera um modelo pelo ID
+	// Retorna model.ErrRoleTemplateNotFound quando o modelo não existe
+	GetTemplate(ctx context.Context, templateID uuid.UUID) (*model.RoleTemplate, error)
+
+	// ListTemplates recupera os modelos que satisfazem o filtro, ordenados por código e mercado
+	ListTemplates(ctx context.Context, filter model.RoleTemplateFilter) ([]*model.RoleTemplate, error)
+
+	// UpdateTemplate grava as alterações de um modelo
+	// Retorna model.ErrRoleTemplateConflict se a versão armazenada já não for a esperada
+	UpdateTemplate(ctx context.Context, template *model.RoleTemplate, expectedVersion int) error
+
+	// ResolvePermissions associa os códigos às permissões ativas do tenant
+	// Os códigos sem permissão correspondente ficam fora do mapa retornado
+	ResolvePermissions(ctx context.Context, tenantID uuid.UUID, codes []string) (map[string]uuid.UUID, error)
+
+	// CreateInstance grava a ligação entre a função do tenant e o modelo
+	// Retorna model.ErrRoleTemplateAlreadyInstantiated se o tenant já tiver uma instância do modelo
+	CreateInstance(ctx context.Context, instance *model.RoleTemplateInstance) error
+
+	// GetInstanceByRole recupera a instância de uma função
+	// Retorna model.ErrRoleTemplateInstanceNotFound quando a função não provém de um modelo
+	GetInstanceByRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.RoleTemplateInstance, error)
+
+	// ListInstances recupera as instâncias do tenant
+	ListInstances(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateInstance, error)
+}
@@ -0,0 +1,58 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a federação SAML 2.0.
+ * Define a persistência dos fornecedores de identidade dos tenants, dos pedidos de
+ * autenticação pendentes e das identidades federadas dos usuários.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// SAMLFederationRepository define a interface para persistência da federação SAML
+type SAMLFederationRepository interface {
+	// CreateProvider grava um novo fornecedor de identidade
+	// Retorna model.ErrSAMLProviderAlreadyExists se o tenant já tiver um IdP com o mesmo entity ID
+	CreateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error
+
+	// GetProvider recupera um fornecedor de identidade pelo ID, independentemente do tenant,
+	// porque os pedidos do IdP chegam sem contexto de tenant
+	// Retorna model.ErrSAMLProviderNotFound quando o fornecedor não existe
+	GetProvider(ctx context.Context, providerID uuid.UUID) (*model.SAMLIdentityProvider, error)
+
+	// ListProviders recupera os fornecedores de identidade do tenant, ordenados por nome
+	ListProviders(ctx context.Context, tenantID uuid.UUID) ([]*model.SAMLIdentityProvider, error)
+
+	// UpdateProvider grava as alterações de um fornecedor de identidade
+	UpdateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error
+
+	// SaveAuthnRequest grava um pedido de autenticação emitido
+	SaveAuthnRequest(ctx context.Context, request *model.SAMLAuthnRequest) error
+
+	// ConsumeAuthnRequest marca como consumido o pedido do fornecedor com o relay state indicado
+	// Retorna model.ErrSAMLRequestNotFound se o pedido não existir, tiver expirado ou já tiver sido consumido
+	ConsumeAuthnRequest(ctx context.Context, providerID uuid.UUID, relayState string, now time.Time) (*model.SAMLAuthnRequest, error)
+
+	// GetFederatedIdentity recupera a identidade federada do NameID emitido pelo fornecedor
+	// Retorna model.ErrFederatedIdentityNotFound quando o NameID ainda não foi ligado a um usuário
+	GetFederatedIdentity(ctx context.Context, providerID uuid.UUID, subject string) (*model.FederatedIdentity, error)
+
+	// GetUser recupera o usuário ligado a uma identidade federada
+	GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error)
+
+	// ProvisionUser cria o usuário, as suas credenciais federadas e a identidade federada numa única transação
+	// Retorna model.ErrSAMLAccountConflict se o email ou o nome de usuário já estiverem em uso no tenant
+	ProvisionUser(ctx context.Context, user *model.User, identity *model.FederatedIdentity) error
+
+	// RecordLogin grava as funções atribuídas pela federação e o instante do login
+	RecordLogin(ctx context.Context, identity *model.FederatedIdentity) error
+}
@@ -0,0 +1,34 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para os incidentes de segurança.
+ * Define a persistência das anomalias detetadas no fluxo de auditoria e da sua triagem.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// SecurityIncidentRepository define a interface para persistência de incidentes de segurança
+type SecurityIncidentRepository interface {
+	// Create grava um novo incidente
+	Create(ctx context.Context, incident *model.SecurityIncident) error
+
+	// Get recupera um incidente pelo ID
+	// Retorna model.ErrSecurityIncidentNotFound quando o incidente não existe no tenant
+	Get(ctx context.Context, tenantID, incidentID uuid.UUID) (*model.SecurityIncident, error)
+
+	// List recupera os incidentes do tenant que satisfazem o filtro, do mais recente para o mais antigo
+	List(ctx context.Context, tenantID uuid.UUID, filter model.SecurityIncidentFilter) ([]*model.SecurityIncident, error)
+
+	// SaveStatus grava o estado e a triagem do incidente
+	// Retorna model.ErrSecurityIncidentConflict se o estado armazenado já não for o esperado
+	SaveStatus(ctx context.Context, incident *model.SecurityIncident, expected model.SecurityIncidentStatus) error
+}
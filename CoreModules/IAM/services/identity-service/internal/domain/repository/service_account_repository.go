@@ -0,0 +1,75 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as contas de serviço.
+ * Define a persistência das contas, das funções atribuídas, das credenciais e da
+ * utilização diária usada para identificar as contas inativas.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// ServiceAccountRepository define a interface para persistência das contas de serviço
+type ServiceAccountRepository interface {
+	// ListServiceAccounts recupera as contas de serviço do tenant, ordenadas pelo nome
+	ListServiceAccounts(ctx context.Context, tenantID uuid.UUID, filter model.ServiceAccountFilter) ([]*model.ServiceAccount, error)
+
+	// GetServiceAccount recupera uma conta de serviço do tenant, com as funções atribuídas
+	// Retorna model.ErrServiceAccountNotFound quando a conta não existe
+	GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.ServiceAccount, error)
+
+	// FindByWorkloadIdentity recupera a conta de serviço ligada à conta de serviço Kubernetes
+	// Retorna model.ErrServiceAccountNotFound quando nenhuma conta está ligada
+	FindByWorkloadIdentity(ctx context.Context, binding model.WorkloadIdentityBinding) (*model.ServiceAccount, error)
+
+	// CreateServiceAccount grava uma nova conta de serviço
+	// Retorna model.ErrServiceAccountConflict quando o nome já existe no tenant
+	CreateServiceAccount(ctx context.Context, account *model.ServiceAccount) error
+
+	// UpdateServiceAccount altera o nome, a descrição, o estado e a ligação Kubernetes de uma conta
+	// Retorna model.ErrServiceAccountNotFound quando a conta não existe, model.ErrServiceAccountConflict
+	// quando o novo nome já existe no tenant e model.ErrWorkloadIdentityConflict quando a conta de
+	// serviço Kubernetes já está ligada a outra conta
+	UpdateServiceAccount(ctx context.Context, account *model.ServiceAccount) error
+
+	// BindRole atribui uma função do tenant à conta de serviço; atribuir uma função já atribuída não tem efeito
+	// Retorna model.ErrServiceAccountNotFound quando a conta não existe e model.ErrServiceAccountRoleNotFound
+	// quando a função não existe no tenant
+	BindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID, grantedAt time.Time) error
+
+	// UnbindRole retira uma função da conta de serviço
+	// Retorna model.ErrServiceAccountRoleNotBound quando a função não está atribuída
+	UnbindRole(ctx context.Context, tenantID, accountID, roleID uuid.UUID) error
+
+	// ListCredentials recupera as credenciais da conta, revogadas ou não, mais recentes primeiro
+	ListCredentials(ctx context.Context, tenantID, accountID uuid.UUID) ([]*model.ServiceAccountCredential, error)
+
+	// FindCredential recupera, em qualquer tenant, a credencial do tipo com o identificador público
+	// indicado: o prefixo da chave de API, o client_id do cliente OIDC ou a impressão digital do certificado
+	// Retorna model.ErrServiceAccountCredentialNotFound quando a credencial não existe
+	FindCredential(ctx context.Context, credentialType model.ServiceAccountCredentialType, identifier string) (*model.ServiceAccountCredential, error)
+
+	// CreateCredential grava uma nova credencial
+	// Retorna model.ErrServiceAccountCredentialConflict quando o identificador público já está registado
+	CreateCredential(ctx context.Context, credential *model.ServiceAccountCredential) error
+
+	// RevokeCredential revoga uma credencial ativa da conta
+	// Retorna model.ErrServiceAccountCredentialNotFound quando a credencial não existe ou já foi revogada
+	RevokeCredential(ctx context.Context, tenantID, accountID, credentialID, actorID uuid.UUID, revokedAt time.Time) error
+
+	// RecordUsage contabiliza uma autenticação no dia e método e atualiza a última utilização
+	// da conta e da credencial
+	RecordUsage(ctx context.Context, usage *model.ServiceAccountUsage) error
+
+	// ListDailyUsage recupera a utilização diária das contas do tenant desde o dia indicado
+	ListDailyUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]*model.ServiceAccountDailyUsage, error)
+}
@@ -0,0 +1,37 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para as políticas de sessão dos tenants.
+ * Define a persistência da validade dos tokens e da duração das sessões, e o
+ * registo de atividade das sessões usado para terminar as sessões inativas.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// SessionPolicyRepository define a interface para persistência das políticas de sessão
+type SessionPolicyRepository interface {
+	// GetPolicy recupera a política do tenant
+	// Retorna nil, nil quando o tenant ainda não configurou a política
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.SessionPolicy, error)
+
+	// SavePolicy grava a política do tenant, substituindo a anterior
+	SavePolicy(ctx context.Context, policy *model.SessionPolicy) error
+
+	// DeletePolicy remove a política do tenant, que volta aos valores padrão do mercado
+	DeletePolicy(ctx context.Context, tenantID uuid.UUID) error
+
+	// TouchSession regista um pedido da sessão numa única transação e retorna o registo resultante
+	// Se o último pedido for anterior a idleSince, ou a sessão já tiver terminado, o pedido não é
+	// registado e a sessão fica terminada (EndedAt preenchido)
+	TouchSession(ctx context.Context, activity *model.SessionActivity, idleSince time.Time) (*model.SessionActivity, error)
+}
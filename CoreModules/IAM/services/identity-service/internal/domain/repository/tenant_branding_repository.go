@@ -0,0 +1,57 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a identidade visual e os modelos de email dos tenants.
+ * Define a persistência da identidade visual e das versões dos modelos de email, com a
+ * publicação atómica de uma versão no lugar da versão publicada do mesmo email e idioma.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// EmailTemplateFilter restringe a listagem das versões dos modelos de email
+// Os campos vazios não restringem a listagem
+type EmailTemplateFilter struct {
+	Kind   model.EmailTemplateKind
+	Locale string
+	Status model.EmailTemplateStatus
+	Limit  int
+}
+
+// TenantBrandingRepository define a interface para persistência da identidade visual e dos modelos de email
+type TenantBrandingRepository interface {
+	// GetBranding recupera a identidade visual do tenant, ou nil se não existir
+	GetBranding(ctx context.Context, tenantID uuid.UUID) (*model.TenantBranding, error)
+
+	// SaveBranding grava a identidade visual do tenant, substituindo a anterior
+	SaveBranding(ctx context.Context, branding *model.TenantBranding) error
+
+	// CreateEmailTemplate grava uma nova versão do modelo com o número seguinte ao da última versão
+	// do mesmo email e idioma. O número atribuído é escrito em template.Version
+	CreateEmailTemplate(ctx context.Context, template *model.EmailTemplate) error
+
+	// GetEmailTemplate recupera uma versão de um modelo do tenant
+	// Retorna model.ErrEmailTemplateNotFound quando a versão não existe
+	GetEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error)
+
+	// ListEmailTemplates recupera as versões dos modelos do tenant, por email e idioma e da mais
+	// recente para a mais antiga
+	ListEmailTemplates(ctx context.Context, tenantID uuid.UUID, filter EmailTemplateFilter) ([]*model.EmailTemplate, error)
+
+	// PublishEmailTemplate publica uma versão numa transação, arquivando a versão publicada do mesmo
+	// email e idioma. Retorna model.ErrEmailTemplateNotFound quando a versão não existe
+	PublishEmailTemplate(ctx context.Context, tenantID, templateID, publishedBy uuid.UUID, publishedAt time.Time) (*model.EmailTemplate, error)
+
+	// ArchiveEmailTemplate arquiva uma versão; o email volta a usar o modelo padrão quando a versão
+	// estava publicada. Retorna model.ErrEmailTemplateNotFound quando a versão não existe
+	ArchiveEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error)
+}
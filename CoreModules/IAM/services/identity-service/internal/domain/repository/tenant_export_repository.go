@@ -0,0 +1,42 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a exportação de dados de tenants.
+ * Define a persistência das exportações e a leitura paginada por chave das secções do arquivo.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// TenantExportRepository define a interface para persistência das exportações de tenants
+type TenantExportRepository interface {
+	// Create grava uma nova exportação
+	// Retorna model.ErrTenantExportInProgress se o tenant já tiver uma exportação pendente ou em geração
+	Create(ctx context.Context, export *model.TenantExport) error
+
+	// Get recupera uma exportação pelo ID
+	// Retorna model.ErrTenantExportNotFound quando a exportação não existe no tenant
+	Get(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error)
+
+	// List recupera as exportações do tenant, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.TenantExport, error)
+
+	// ListActive recupera as exportações pendentes ou em geração de todos os tenants, da mais antiga para a mais recente
+	ListActive(ctx context.Context, limit int) ([]*model.TenantExport, error)
+
+	// Save grava o estado, o progresso e o manifesto da exportação e incrementa a sua versão
+	// Retorna model.ErrTenantExportConflict se a versão armazenada já não for a esperada
+	Save(ctx context.Context, export *model.TenantExport, expectedVersion int64) error
+
+	// ReadSection recupera até limit linhas da secção do tenant, ordenadas pela chave,
+	// a partir da linha seguinte ao cursor (vazio para começar do início)
+	ReadSection(ctx context.Context, tenantID uuid.UUID, section model.TenantExportSection, cursor string, limit int) ([]model.TenantExportRecord, error)
+}
@@ -0,0 +1,51 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para a troca de tokens (RFC 8693).
+ * Define a persistência das políticas por audiência e do registo de auditoria
+ * das trocas concedidas e recusadas.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// TokenExchangeRepository define a interface para persistência da troca de tokens
+type TokenExchangeRepository interface {
+	// ListPolicies recupera todas as políticas do tenant, ativas ou não
+	ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.TokenExchangePolicy, error)
+
+	// GetPolicy recupera uma política do tenant
+	// Retorna model.ErrTokenExchangePolicyNotFound quando a política não existe
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.TokenExchangePolicy, error)
+
+	// GetPolicyByAudience recupera a política do tenant para a audiência
+	// Retorna model.ErrTokenExchangePolicyNotFound quando a audiência não tem política
+	GetPolicyByAudience(ctx context.Context, tenantID uuid.UUID, audience string) (*model.TokenExchangePolicy, error)
+
+	// CreatePolicy grava uma nova política
+	// Retorna model.ErrTokenExchangePolicyConflict quando a audiência já tem política no tenant
+	CreatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error
+
+	// UpdatePolicy altera uma política existente
+	// Retorna model.ErrTokenExchangePolicyNotFound quando a política não existe e
+	// model.ErrTokenExchangePolicyConflict quando a nova audiência já tem política no tenant
+	UpdatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error
+
+	// DeletePolicy remove uma política do tenant
+	// Retorna model.ErrTokenExchangePolicyNotFound quando a política não existe
+	DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error
+
+	// SaveEvent acrescenta uma troca ao registo de auditoria
+	SaveEvent(ctx context.Context, event *model.TokenExchangeEvent) error
+
+	// ListEvents recupera o registo de auditoria do tenant, mais recentes primeiro
+	ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.TokenExchangeEventFilter) ([]*model.TokenExchangeEvent, error)
+}
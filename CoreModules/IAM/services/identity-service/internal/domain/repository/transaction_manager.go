@@ -0,0 +1,20 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface para transações que abrangem vários repositórios.
+ * Permite gravar uma alteração e o seu histórico de forma atómica.
+ */
+
+package repository
+
+import (
+	"context"
+)
+
+// TransactionManager executa operações de vários repositórios numa única transação
+type TransactionManager interface {
+	// WithinTransaction executa fn numa transação; os repositórios chamados com o contexto
+	// recebido participam nela. Um erro de fn reverte todas as operações
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,49 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para os atributos personalizados dos usuários.
+ * Define a persistência dos esquemas dos atributos de cada tenant e dos valores
+ * dos atributos de cada usuário.
+ */
+
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// UserAttributeRepository define a interface para persistência dos atributos dos usuários
+type UserAttributeRepository interface {
+	// ListSchemas recupera os esquemas dos atributos do tenant, ordenados pelo nome
+	ListSchemas(ctx context.Context, tenantID uuid.UUID) ([]*model.UserAttributeSchema, error)
+
+	// GetSchema recupera um esquema do tenant
+	// Retorna model.ErrUserAttributeSchemaNotFound quando o esquema não existe
+	GetSchema(ctx context.Context, tenantID, schemaID uuid.UUID) (*model.UserAttributeSchema, error)
+
+	// CreateSchema grava um novo esquema
+	// Retorna model.ErrUserAttributeSchemaConflict quando o nome ou a claim já existem no tenant
+	CreateSchema(ctx context.Context, schema *model.UserAttributeSchema) error
+
+	// UpdateSchema altera as restrições, a obrigatoriedade e a claim de um esquema
+	// Retorna model.ErrUserAttributeSchemaNotFound quando o esquema não existe e
+	// model.ErrUserAttributeSchemaConflict quando a claim já existe no tenant
+	UpdateSchema(ctx context.Context, schema *model.UserAttributeSchema) error
+
+	// DeleteSchema remove o esquema e o atributo de todos os usuários do tenant, atomicamente
+	// Retorna model.ErrUserAttributeSchemaNotFound quando o esquema não existe
+	DeleteSchema(ctx context.Context, tenantID, schemaID uuid.UUID) error
+
+	// GetUserAttributes recupera os atributos do usuário; sem atributos gravados, os valores ficam vazios
+	// Retorna model.ErrUserNotFound quando o usuário não existe no tenant
+	GetUserAttributes(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributes, error)
+
+	// SaveUserAttributes grava os atributos do usuário, substituindo os anteriores
+	// Retorna model.ErrUserNotFound quando o usuário não existe no tenant
+	SaveUserAttributes(ctx context.Context, attributes *model.UserAttributes) error
+}
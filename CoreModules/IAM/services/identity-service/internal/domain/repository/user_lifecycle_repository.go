@@ -0,0 +1,48 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Interface de repositório para o ciclo de vida de contas de usuário.
+ * Define a persistência das transições de estado e das políticas de automação por tenant.
+ */
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/innovabiz/iam/services/identity-service/internal/domain/model"
+)
+
+// UserLifecycleRepository define a interface para persistência do ciclo de vida de usuários
+type UserLifecycleRepository interface {
+	// GetUser recupera o usuário com os dados de ciclo de vida
+	GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error)
+
+	// SaveTransition grava o novo estado do usuário e o registo da transição numa transação
+	// Retorna model.ErrUserLifecycleConflict se o estado de origem já não corresponder ao armazenado
+	// Suspensões e desprovisionamentos revogam as sessões ativas do usuário
+	SaveTransition(ctx context.Context, user *model.User, transition *model.UserLifecycleTransition) error
+
+	// ListTransitions recupera as transições do usuário em ordem cronológica
+	ListTransitions(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.UserLifecycleTransition, error)
+
+	// FindInactiveUsers recupera usuários ativos sem atividade desde o instante indicado
+	FindInactiveUsers(ctx context.Context, tenantID uuid.UUID, inactiveSince time.Time, limit int) ([]*model.User, error)
+
+	// FindSuspendedUsers recupera usuários suspensos desde antes do instante indicado
+	FindSuspendedUsers(ctx context.Context, tenantID uuid.UUID, suspendedBefore time.Time, limit int) ([]*model.User, error)
+
+	// GetPolicy recupera a política de ciclo de vida do tenant
+	// Retorna model.ErrUserLifecyclePolicyNotFound quando o tenant não tem política própria
+	GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.UserLifecyclePolicy, error)
+
+	// SavePolicy cria ou substitui a política de ciclo de vida do tenant
+	SavePolicy(ctx context.Context, policy *model.UserLifecyclePolicy) error
+
+	// ListPolicies recupera as políticas de todos os tenants
+	ListPolicies(ctx context.Context) ([]*model.UserLifecyclePolicy, error)
+}
@@ -0,0 +1,108 @@
+package requestctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Erros da verificação do tenant do contexto
+var (
+	ErrTenantRequired = errors.New("operação sem tenant no contexto")
+	ErrTenantMismatch = errors.New("tenant da operação difere do tenant do contexto")
+)
+
+// Chaves do contexto, privadas para que os valores só possam ser definidos por este pacote
+type contextKey int
+
+const (
+	tenantKey contextKey = iota
+	marketKey
+	actorKey
+	systemScopeKey
+)
+
+// Actor identifica quem executa o pedido
+type Actor struct {
+	UserID   uuid.UUID
+	Username string
+	Roles    []string
+	// Serviço que age em nome do usuário, nos pedidos com token delegado
+	Delegation *model.ActorClaim
+}
+
+// WithTenant retorna um contexto com o tenant do pedido
+func WithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// TenantFromContext retorna o tenant do pedido; ok é falso quando o contexto não o tem
+func TenantFromContext(ctx context.Context) (tenantID uuid.UUID, ok bool) {
+	tenantID, ok = ctx.Value(tenantKey).(uuid.UUID)
+	return tenantID, ok && tenantID != uuid.Nil
+}
+
+// WithMarket retorna um contexto com o mercado do pedido
+func WithMarket(ctx context.Context, market string) context.Context {
+	return context.WithValue(ctx, marketKey, market)
+}
+
+// MarketFromContext retorna o mercado do pedido, vazio quando o contexto não o tem
+func MarketFromContext(ctx context.Context) string {
+	market, _ := ctx.Value(marketKey).(string)
+	return market
+}
+
+// WithActor retorna um contexto com o ator do pedido
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext retorna o ator do pedido; ok é falso quando o contexto não o tem
+func ActorFromContext(ctx context.Context) (actor Actor, ok bool) {
+	actor, ok = ctx.Value(actorKey).(Actor)
+	return actor, ok
+}
+
+// WithSystemScope marca o contexto das tarefas do sistema que operam sobre todos os tenants,
+// como os agendadores; o componente identifica a tarefa nos erros e nos registos
+func WithSystemScope(ctx context.Context, component string) context.Context {
+	return context.WithValue(ctx, systemScopeKey, component)
+}
+
+// SystemScope retorna o componente do sistema que executa a operação sem tenant
+func SystemScope(ctx context.Context) (component string, ok bool) {
+	component, ok = ctx.Value(systemScopeKey).(string)
+	return component, ok
+}
+
+// RequireTenant retorna o tenant do contexto, recusando as operações sem tenant
+// No âmbito do sistema a operação é aceite sem tenant e é retornado uuid.Nil
+func RequireTenant(ctx context.Context) (uuid.UUID, error) {
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		return tenantID, nil
+	}
+	if _, ok := SystemScope(ctx); ok {
+		return uuid.Nil, nil
+	}
+	return uuid.Nil, ErrTenantRequired
+}
+
+// EnsureTenant retorna um contexto com o tenant indicado, nos fluxos públicos em que o tenant
+// é conhecido pelo pedido e não pela autenticação; um tenant diferente já no contexto é recusado
+func EnsureTenant(ctx context.Context, tenantID uuid.UUID) (context.Context, error) {
+	if tenantID == uuid.Nil {
+		return ctx, ErrTenantRequired
+	}
+	if current, ok := TenantFromContext(ctx); ok {
+		if current != tenantID {
+			return ctx, fmt.Errorf("%w: %s", ErrTenantMismatch, tenantID)
+		}
+		return ctx, nil
+	}
+	return WithTenant(ctx, tenantID), nil
+}
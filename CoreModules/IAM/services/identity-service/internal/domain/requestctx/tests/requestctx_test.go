@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+)
+
+// TestRequestContextAccessors verifica que o tenant, o mercado e o ator injetados são recuperados
+func TestRequestContextAccessors(t *testing.T) {
+	ctx := context.Background()
+	_, ok := requestctx.TenantFromContext(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, requestctx.MarketFromContext(ctx))
+	_, ok = requestctx.ActorFromContext(ctx)
+	assert.False(t, ok)
+
+	tenantID := uuid.New()
+	actor := requestctx.Actor{
+		UserID:     uuid.New(),
+		Username:   "ana.silva",
+		Roles:      []string{"ANALYST"},
+		Delegation: &model.ActorClaim{Subject: "svc-reporting"},
+	}
+	ctx = requestctx.WithTenant(ctx, tenantID)
+	ctx = requestctx.WithMarket(ctx, "angola")
+	ctx = requestctx.WithActor(ctx, actor)
+
+	got, ok := requestctx.TenantFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, tenantID, got)
+	assert.Equal(t, "angola", requestctx.MarketFromContext(ctx))
+	gotActor, ok := requestctx.ActorFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, actor, gotActor)
+
+	// Um tenant nulo não conta como tenant do pedido
+	_, ok = requestctx.TenantFromContext(requestctx.WithTenant(context.Background(), uuid.Nil))
+	assert.False(t, ok)
+
+	// Os valores não podem ser forjados com chaves de outros pacotes
+	forged := context.WithValue(context.Background(), "tenant_id", tenantID)
+	_, ok = requestctx.TenantFromContext(forged)
+	assert.False(t, ok)
+}
+
+// TestRequireTenant verifica que as operações exigem o tenant, exceto no âmbito do sistema
+func TestRequireTenant(t *testing.T) {
+	_, err := requestctx.RequireTenant(context.Background())
+	assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+
+	tenantID := uuid.New()
+	got, err := requestctx.RequireTenant(requestctx.WithTenant(context.Background(), tenantID))
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, got)
+
+	system := requestctx.WithSystemScope(context.Background(), "user_lifecycle_scheduler")
+	got, err = requestctx.RequireTenant(system)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, got)
+	component, ok := requestctx.SystemScope(system)
+	require.True(t, ok)
+	assert.Equal(t, "user_lifecycle_scheduler", component)
+
+	// Uma tarefa do sistema que passa a operar num tenant fica limitada a esse tenant
+	got, err = requestctx.RequireTenant(requestctx.WithTenant(system, tenantID))
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, got)
+}
+
+// TestEnsureTenant verifica a injeção do tenant nos fluxos públicos
+func TestEnsureTenant(t *testing.T) {
+	tenantID := uuid.New()
+
+	ctx, err := requestctx.EnsureTenant(context.Background(), tenantID)
+	require.NoError(t, err)
+	got, ok := requestctx.TenantFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, tenantID, got)
+
+	// O mesmo tenant já autenticado é aceite
+	_, err = requestctx.EnsureTenant(ctx, tenantID)
+	assert.NoError(t, err)
+
+	// Um pedido para outro tenant não pode substituir o tenant autenticado
+	_, err = requestctx.EnsureTenant(ctx, uuid.New())
+	assert.ErrorIs(t, err, requestctx.ErrTenantMismatch)
+
+	_, err = requestctx.EnsureTenant(context.Background(), uuid.Nil)
+	assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+}
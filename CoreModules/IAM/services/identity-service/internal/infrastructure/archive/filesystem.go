@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Permissões dos diretórios e ficheiros dos arquivos, restritas ao utilizador do serviço
+const (
+	directoryMode = 0o700
+	fileMode      = 0o600
+)
+
+// Filesystem implementa application.TenantExportArchive num diretório local
+// Cada exportação tem um subdiretório próprio, nomeado pelo seu ID
+type Filesystem struct {
+	root string
+}
+
+// NewFilesystem cria o arquivo no diretório indicado, criando-o se necessário
+func NewFilesystem(root string) (*Filesystem, error) {
+	if root == "" {
+		return nil, fmt.Errorf("diretório dos arquivos de exportação obrigatório")
+	}
+	if err := os.MkdirAll(root, directoryMode); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório dos arquivos de exportação: %w", err)
+	}
+	return &Filesystem{root: root}, nil
+}
+
+// Append acrescenta os dados ao ficheiro e retorna o tamanho resultante
+// O ficheiro é sincronizado com o disco antes de o tamanho ser confirmado
+func (f *Filesystem) Append(_ context.Context, exportID uuid.UUID, name string, data []byte) (int64, error) {
+	path, err := f.path(exportID, name)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileMode)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Truncate repõe o ficheiro no tamanho indicado, criando-o vazio se não existir
+func (f *Filesystem) Truncate(_ context.Context, exportID uuid.UUID, name string, size int64) error {
+	path, err := f.path(exportID, name)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < size {
+		return fmt.Errorf("ficheiro %s com %d bytes, inferior ao tamanho confirmado %d", name, info.Size(), size)
+	}
+	return file.Truncate(size)
+}
+
+// Open abre o ficheiro para leitura
+func (f *Filesystem) Open(_ context.Context, exportID uuid.UUID, name string) (io.ReadCloser, error) {
+	path, err := f.path(exportID, name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// path retorna o caminho do ficheiro, criando o diretório da exportação
+// Os nomes não podem conter separadores, impedindo o acesso fora do diretório da exportação
+func (f *Filesystem) path(exportID uuid.UUID, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("nome de ficheiro inválido %q", name)
+	}
+
+	dir := filepath.Join(f.root, exportID.String())
+	if err := os.MkdirAll(dir, directoryMode); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// entry é o valor gravado na cache. A chave por ID guarda a entidade; a chave por código guarda
+// apenas o ID, para que uma alteração invalide uma única chave. Found falso é uma entrada negativa
+type entry struct {
+	Found bool            `json:"found"`
+	ID    uuid.UUID       `json:"id,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// entityCache implementa a leitura através da cache de uma entidade consultada por ID e por código.
+// As consultas concorrentes da mesma chave são agregadas numa única leitura do repositório
+type entityCache[T any] struct {
+	entity   string // Nome da entidade nas chaves e nas métricas (role, permission)
+	store    Store
+	config   Config
+	notFound error // Erro do repositório para entidades inexistentes
+	codeOf   func(*T) string
+	idOf     func(*T) uuid.UUID
+	group    singleflight.Group
+
+	// Contador das invalidações: uma leitura durante a qual houve invalidações não grava o
+	// resultado, que pode ser anterior à alteração
+	invalidations atomic.Uint64
+}
+
+// newEntityCache cria a cache de uma entidade
+func newEntityCache[T any](entity string, store Store, config Config, notFound error, idOf func(*T) uuid.UUID, codeOf func(*T) string) *entityCache[T] {
+	return &entityCache[T]{
+		entity:   entity,
+		store:    store,
+		config:   config.withDefaults(),
+		notFound: notFound,
+		idOf:     idOf,
+		codeOf:   codeOf,
+	}
+}
+
+// idKey retorna a chave da entidade por ID
+func (c *entityCache[T]) idKey(tenantID, id uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:%s:id:%s", c.config.KeyPrefix, c.entity, tenantID, id)
+}
+
+// codeKey retorna a chave do ID da entidade por código
+func (c *entityCache[T]) codeKey(tenantID uuid.UUID, code string) string {
+	return fmt.Sprintf("%s:%s:%s:code:%s", c.config.KeyPrefix, c.entity, tenantID, code)
+}
+
+// byID retorna a entidade da cache ou, na falta, do repositório
+func (c *entityCache[T]) byID(ctx context.Context, tenantID, id uuid.UUID, load func(context.Context) (*T, error)) (*T, error) {
+	key := c.idKey(tenantID, id)
+	cached, result := c.get(ctx, key)
+	switch result {
+	case resultNegativeHit:
+		c.record("id", result)
+		return nil, c.notFound
+	case resultHit:
+		if value, err := c.decode(cached.Value); err == nil {
+			c.record("id", result)
+			return value, nil
+		}
+		result = resultError
+	}
+	c.record("id", result)
+
+	return c.load(ctx, key, "id", func(ctx context.Context, generation uint64) (*entry, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.fill(ctx, tenantID, value, generation)
+	})
+}
+
+// byCode retorna a entidade da cache ou, na falta, do repositório. O ID guardado no código só é
+// usado se a entidade em cache ainda tiver o mesmo código, pois os códigos podem ser alterados
+func (c *entityCache[T]) byCode(ctx context.Context, tenantID uuid.UUID, code string, load func(context.Context) (*T, error)) (*T, error) {
+	key := c.codeKey(tenantID, code)
+	pointer, result := c.get(ctx, key)
+	switch result {
+	case resultNegativeHit:
+		c.record("code", result)
+		return nil, c.notFound
+	case resultHit:
+		// A entidade pode ter sido invalidada desde que o código foi guardado: a consulta conta como falha
+		result = resultMiss
+		if cached, status := c.get(ctx, c.idKey(tenantID, pointer.ID)); status == resultHit {
+			if value, err := c.decode(cached.Value); err == nil && c.codeOf(value) == code {
+				c.record("code", resultHit)
+				return value, nil
+			}
+		}
+	}
+	c.record("code", result)
+
+	return c.load(ctx, key, "code", func(ctx context.Context, generation uint64) (*entry, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		loaded, err := c.fill(ctx, tenantID, value, generation)
+		if err != nil {
+			return nil, err
+		}
+		c.set(ctx, key, entry{Found: true, ID: loaded.ID}, c.ttl(), generation)
+		return loaded, nil
+	})
+}
+
+// load lê a entidade do repositório uma única vez por chave, mesmo com consultas concorrentes,
+// e grava uma entrada negativa quando a entidade não existe. Cada chamador recebe a sua cópia
+func (c *entityCache[T]) load(ctx context.Context, key, lookup string, read func(context.Context, uint64) (*entry, error)) (*T, error) {
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		cacheLoadsTotal.WithLabelValues(c.entity, lookup).Inc()
+		// A leitura partilhada não é cancelada pelo pedido que a iniciou
+		ctx := context.WithoutCancel(ctx)
+		generation := c.invalidations.Load()
+		loaded, err := read(ctx, generation)
+		if errors.Is(err, c.notFound) {
+			c.set(ctx, key, entry{Found: false}, c.config.NegativeTTL, generation)
+			return nil, c.notFound
+		}
+		return loaded, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(result.(*entry).Value)
+}
+
+// fill grava a entidade lida do repositório na chave por ID
+func (c *entityCache[T]) fill(ctx context.Context, tenantID uuid.UUID, value *T, generation uint64) (*entry, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar %s para a cache: %w", c.entity, err)
+	}
+	loaded := entry{Found: true, ID: c.idOf(value), Value: encoded}
+	c.set(ctx, c.idKey(tenantID, loaded.ID), loaded, c.ttl(), generation)
+	return &loaded, nil
+}
+
+// invalidate remove a entidade por ID e os códigos indicados, incluindo entradas negativas,
+// e descarta as leituras em curso dessas chaves
+func (c *entityCache[T]) invalidate(ctx context.Context, tenantID, id uuid.UUID, source string, codes ...string) {
+	keys := []string{c.idKey(tenantID, id)}
+	for _, code := range codes {
+		if code != "" {
+			keys = append(keys, c.codeKey(tenantID, code))
+		}
+	}
+	c.invalidations.Add(1)
+	for _, key := range keys {
+		c.group.Forget(key)
+	}
+
+	cacheInvalidationsTotal.WithLabelValues(c.entity, source).Inc()
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		log.Ctx(ctx).Error().Err(err).
+			Str("entity", c.entity).
+			Str("tenant_id", tenantID.String()).
+			Str("id", id.String()).
+			Msg("Erro ao invalidar a cache do repositório")
+	}
+}
+
+// get lê uma entrada da cache e retorna o resultado da consulta. Uma falha do Redis é tratada
+// como falha da cache, para que a consulta siga para o repositório
+func (c *entityCache[T]) get(ctx context.Context, key string) (*entry, string) {
+	raw, err := c.store.Get(ctx, key)
+	if errors.Is(err, ErrMiss) {
+		return nil, resultMiss
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Cache do repositório indisponível")
+		return nil, resultError
+	}
+
+	var cached entry
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Entrada inválida na cache do repositório")
+		return nil, resultError
+	}
+	if !cached.Found {
+		return &cached, resultNegativeHit
+	}
+	return &cached, resultHit
+}
+
+// record regista o resultado de uma consulta nas métricas
+func (c *entityCache[T]) record(lookup, result string) {
+	cacheRequestsTotal.WithLabelValues(c.entity, lookup, result).Inc()
+}
+
+// set grava uma entrada na cache, exceto se houve invalidações desde o início da leitura;
+// uma falha do Redis não interrompe a consulta
+func (c *entityCache[T]) set(ctx context.Context, key string, value entry, ttl time.Duration, generation uint64) {
+	if c.invalidations.Load() != generation {
+		return
+	}
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		err = c.store.Set(ctx, key, encoded, ttl)
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Erro ao gravar na cache do repositório")
+	}
+}
+
+// decode retorna uma nova cópia da entidade serializada
+func (c *entityCache[T]) decode(raw json.RawMessage) (*T, error) {
+	value := new(T)
+	if err := json.Unmarshal(raw, value); err != nil {
+		return nil, fmt.Errorf("erro ao ler %s da cache: %w", c.entity, err)
+	}
+	return value, nil
+}
+
+// ttl retorna a validade das entradas encontradas com a variação aleatória configurada
+func (c *entityCache[T]) ttl() time.Duration {
+	return c.config.TTL + time.Duration(rand.Float64()*c.config.TTLJitter*float64(c.config.TTL))
+}
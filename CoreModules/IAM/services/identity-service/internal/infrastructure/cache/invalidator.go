@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+)
+
+// InvalidationTopics são os tópicos cujas alterações invalidam a cache dos repositórios
+var InvalidationTopics = []string{
+	event.TopicRoleCreated,
+	event.TopicRoleUpdated,
+	event.TopicRoleSoftDeleted,
+	event.TopicRoleHardDeleted,
+	event.TopicPermissionCreated,
+	event.TopicPermissionUpdated,
+	event.TopicPermissionDeleted,
+}
+
+// Invalidator invalida a cache dos repositórios com as alterações de funções e permissões
+// publicadas no barramento, incluindo as feitas por outras instâncias do serviço
+type Invalidator struct {
+	roles       *RoleRepository
+	permissions *PermissionRepository
+
+	mutex   sync.Mutex
+	bus     event.EventBus
+	handler func(ctx context.Context, evt event.Event) error
+}
+
+// NewInvalidator cria uma nova instância de Invalidator
+// Qualquer um dos repositórios pode ser nil quando a sua cache não está ativa
+func NewInvalidator(roles *RoleRepository, permissions *PermissionRepository) *Invalidator {
+	return &Invalidator{roles: roles, permissions: permissions}
+}
+
+// Attach assina no barramento os tópicos das alterações de funções e permissões
+func (i *Invalidator) Attach(bus event.EventBus) error {
+	handler := i.HandleEvent
+	for _, topic := range InvalidationTopics {
+		if err := bus.Subscribe(topic, handler); err != nil {
+			return fmt.Errorf("erro ao assinar o tópico %s: %w", topic, err)
+		}
+	}
+
+	i.mutex.Lock()
+	i.bus = bus
+	i.handler = handler
+	i.mutex.Unlock()
+	return nil
+}
+
+// Close cancela as assinaturas no barramento
+func (i *Invalidator) Close() error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.bus == nil {
+		return nil
+	}
+	var firstErr error
+	for _, topic := range InvalidationTopics {
+		if err := i.bus.Unsubscribe(topic, i.handler); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("erro ao cancelar a assinatura do tópico %s: %w", topic, err)
+		}
+	}
+	i.bus = nil
+	return firstErr
+}
+
+// HandleEvent invalida a entrada da função ou permissão alterada; outros eventos são ignorados
+func (i *Invalidator) HandleEvent(ctx context.Context, evt event.Event) error {
+	switch e := evt.(type) {
+	case *event.RoleCreatedEvent:
+		i.invalidateRole(ctx, e.TenantID, e.RoleID, e.Code)
+	case *event.RoleUpdatedEvent:
+		i.invalidateRole(ctx, e.TenantID, e.RoleID, e.Code)
+	case *event.RoleSoftDeletedEvent:
+		i.invalidateRole(ctx, e.TenantID, e.RoleID, e.Code)
+	case *event.RoleHardDeletedEvent:
+		i.invalidateRole(ctx, e.TenantID, e.RoleID, e.Code)
+	case event.PermissionEvent:
+		if i.permissions != nil {
+			i.permissions.InvalidatePermission(ctx, e.GetTenantID(), e.GetPermissionID(), e.GetCode())
+		}
+	}
+	return nil
+}
+
+// invalidateRole invalida a entrada da função quando a cache de funções está ativa
+func (i *Invalidator) invalidateRole(ctx context.Context, tenantID, roleID uuid.UUID, code string) {
+	if i.roles != nil {
+		i.roles.InvalidateRole(ctx, tenantID, roleID, code)
+	}
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// PermissionRepository decora o repositório de permissões com a cache de leitura das consultas
+// por ID e por código. As demais operações são delegadas ao repositório decorado
+type PermissionRepository struct {
+	repository.PermissionRepository
+	cache     *entityCache[model.Permission]
+	publisher event.Publisher
+}
+
+// NewPermissionRepository cria uma nova instância de PermissionRepository
+// Com um publisher, as alterações são publicadas para invalidar a cache das outras instâncias
+func NewPermissionRepository(next repository.PermissionRepository, store Store, config Config, publisher event.Publisher) *PermissionRepository {
+	return &PermissionRepository{
+		PermissionRepository: next,
+		cache: newEntityCache(
+			"permission", store, config, repository.ErrPermissionNotFound,
+			func(permission *model.Permission) uuid.UUID { return permission.ID },
+			func(permission *model.Permission) string { return permission.Code },
+		),
+		publisher: publisher,
+	}
+}
+
+// GetByID recupera uma permissão pelo seu ID, da cache sempre que possível
+func (r *PermissionRepository) GetByID(ctx context.Context, tenantID, permissionID uuid.UUID) (*model.Permission, error) {
+	return r.cache.byID(ctx, tenantID, permissionID, func(ctx context.Context) (*model.Permission, error) {
+		return r.PermissionRepository.GetByID(ctx, tenantID, permissionID)
+	})
+}
+
+// GetByCode recupera uma permissão pelo seu código, da cache sempre que possível
+func (r *PermissionRepository) GetByCode(ctx context.Context, tenantID uuid.UUID, code string) (*model.Permission, error) {
+	return r.cache.byCode(ctx, tenantID, code, func(ctx context.Context) (*model.Permission, error) {
+		return r.PermissionRepository.GetByCode(ctx, tenantID, code)
+	})
+}
+
+// Create cria uma nova permissão e remove as entradas negativas do seu ID e código
+func (r *PermissionRepository) Create(ctx context.Context, permission *model.Permission) error {
+	if err := r.PermissionRepository.Create(ctx, permission); err != nil {
+		return err
+	}
+	r.cache.invalidate(ctx, permission.TenantID, permission.ID, InvalidationWrite, permission.Code)
+	r.publish(ctx, event.TopicPermissionCreated, permission.TenantID, permission.ID, permission.Code)
+	return nil
+}
+
+// Update atualiza uma permissão existente e invalida a sua entrada na cache
+func (r *PermissionRepository) Update(ctx context.Context, permission *model.Permission) error {
+	err := r.PermissionRepository.Update(ctx, permission)
+	// A invalidação também ocorre em caso de erro, pois a alteração pode ter sido aplicada
+	r.cache.invalidate(ctx, permission.TenantID, permission.ID, InvalidationWrite, permission.Code)
+	if err != nil {
+		return err
+	}
+	r.publish(ctx, event.TopicPermissionUpdated, permission.TenantID, permission.ID, permission.Code)
+	return nil
+}
+
+// Delete exclui uma permissão e invalida a sua entrada na cache. A chave do código deixa de
+// resolver, pois aponta para um ID que já não está em cache
+func (r *PermissionRepository) Delete(ctx context.Context, tenantID, permissionID uuid.UUID) error {
+	err := r.PermissionRepository.Delete(ctx, tenantID, permissionID)
+	r.cache.invalidate(ctx, tenantID, permissionID, InvalidationWrite)
+	if err != nil {
+		return err
+	}
+	r.publish(ctx, event.TopicPermissionDeleted, tenantID, permissionID, "")
+	return nil
+}
+
+// InvalidatePermission remove da cache a permissão e os códigos indicados
+// Usado pelas alterações recebidas de outras instâncias do serviço
+func (r *PermissionRepository) InvalidatePermission(ctx context.Context, tenantID, permissionID uuid.UUID, codes ...string) {
+	r.cache.invalidate(ctx, tenantID, permissionID, InvalidationEvent, codes...)
+}
+
+// publish publica a alteração da permissão; uma falha não interrompe a operação
+func (r *PermissionRepository) publish(ctx context.Context, topic string, tenantID, permissionID uuid.UUID, code string) {
+	if r.publisher == nil {
+		return
+	}
+	evt := event.NewPermissionChangedEvent(topic, tenantID, permissionID, code)
+	if err := r.publisher.Publish(ctx, evt); err != nil {
+		log.Ctx(ctx).Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("permission_id", permissionID.String()).
+			Str("topic", topic).
+			Msg("Erro ao publicar evento de alteração da permissão")
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// RoleRepository decora o repositório de funções com a cache de leitura das consultas por ID e
+// por código. As demais operações são delegadas ao repositório decorado
+type RoleRepository struct {
+	repository.RoleRepository
+	cache *entityCache[model.Role]
+}
+
+// NewRoleRepository cria uma nova instância de RoleRepository
+func NewRoleRepository(next repository.RoleRepository, store Store, config Config) *RoleRepository {
+	return &RoleRepository{
+		RoleRepository: next,
+		cache: newEntityCache(
+			"role", store, config, repository.ErrRoleNotFound,
+			func(role *model.Role) uuid.UUID { return role.ID },
+			func(role *model.Role) string { return role.Code },
+		),
+	}
+}
+
+// GetByID recupera uma função pelo seu ID, da cache sempre que possível
+func (r *RoleRepository) GetByID(ctx context.Context, tenantID, roleID uuid.UUID) (*model.Role, error) {
+	return r.cache.byID(ctx, tenantID, roleID, func(ctx context.Context) (*model.Role, error) {
+		return r.RoleRepository.GetByID(ctx, tenantID, roleID)
+	})
+}
+
+// GetByCode recupera uma função pelo seu código, da cache sempre que possível
+func (r *RoleRepository) GetByCode(ctx context.Context, tenantID uuid.UUID, code string) (*model.Role, error) {
+	return r.cache.byCode(ctx, tenantID, code, func(ctx context.Context) (*model.Role, error) {
+		return r.RoleRepository.GetByCode(ctx, tenantID, code)
+	})
+}
+
+// Create cria uma nova função e remove as entradas negativas do seu ID e código
+func (r *RoleRepository) Create(ctx context.Context, role *model.Role) error {
+	if err := r.RoleRepository.Create(ctx, role); err != nil {
+		return err
+	}
+	r.cache.invalidate(ctx, role.TenantID, role.ID, InvalidationWrite, role.Code)
+	return nil
+}
+
+// Update atualiza uma função existente e invalida a sua entrada na cache
+func (r *RoleRepository) Update(ctx context.Context, role *model.Role) error {
+	err := r.RoleRepository.Update(ctx, role)
+	// A invalidação também ocorre em caso de erro, pois a alteração pode ter sido aplicada
+	r.cache.invalidate(ctx, role.TenantID, role.ID, InvalidationWrite, role.Code)
+	return err
+}
+
+// Delete exclui uma função e invalida a sua entrada na cache. A chave do código deixa de
+// resolver, pois aponta para um ID que já não está em cache
+func (r *RoleRepository) Delete(ctx context.Context, tenantID, roleID uuid.UUID) error {
+	err := r.RoleRepository.Delete(ctx, tenantID, roleID)
+	r.cache.invalidate(ctx, tenantID, roleID, InvalidationWrite)
+	return err
+}
+
+// InvalidateRole remove da cache a função e os códigos indicados
+// Usado pelas alterações recebidas de outras instâncias do serviço
+func (r *RoleRepository) InvalidateRole(ctx context.Context, tenantID, roleID uuid.UUID, codes ...string) {
+	r.cache.invalidate(ctx, tenantID, roleID, InvalidationEvent, codes...)
+}
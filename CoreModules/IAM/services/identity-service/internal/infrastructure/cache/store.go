@@ -0,0 +1,160 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Cache de leitura (read-through) dos repositórios de funções e permissões.
+ * As consultas por ID e por código das avaliações de permissões são servidas pelo Redis,
+ * com invalidação nas alterações, cache negativa e proteção contra avalanches de recarga.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// Valores padrão da cache dos repositórios
+const (
+	DefaultKeyPrefix   = "iam:cache"
+	DefaultTTL         = 5 * time.Minute
+	DefaultNegativeTTL = 30 * time.Second
+	DefaultTTLJitter   = 0.1
+)
+
+// Resultados das consultas registados nas métricas
+const (
+	resultHit         = "hit"
+	resultNegativeHit = "negative_hit"
+	resultMiss        = "miss"
+	resultError       = "error"
+)
+
+// Origens das invalidações registadas nas métricas
+const (
+	InvalidationWrite = "write" // Alteração feita através do repositório decorado
+	InvalidationEvent = "event" // Alteração recebida pelo barramento de eventos
+)
+
+// Métricas da cache dos repositórios; a taxa de acertos é hit / (hit + negative_hit + miss)
+var (
+	cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "iam",
+		Subsystem: "repository_cache",
+		Name:      "requests_total",
+		Help:      "Consultas à cache dos repositórios por entidade, tipo de consulta e resultado",
+	}, []string{"entity", "lookup", "result"})
+
+	cacheLoadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "iam",
+		Subsystem: "repository_cache",
+		Name:      "loads_total",
+		Help:      "Leituras do repositório após falhas da cache, depois de agregadas as consultas concorrentes",
+	}, []string{"entity", "lookup"})
+
+	cacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "iam",
+		Subsystem: "repository_cache",
+		Name:      "invalidations_total",
+		Help:      "Invalidações da cache dos repositórios por entidade e origem",
+	}, []string{"entity", "source"})
+)
+
+// ErrMiss indica que a chave não está na cache
+var ErrMiss = errors.New("chave ausente da cache")
+
+// Store guarda as entradas serializadas da cache
+type Store interface {
+	// Get retorna o valor da chave ou ErrMiss quando a chave não existe
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set grava o valor da chave com a validade indicada
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete remove as chaves indicadas
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// RedisStore implementa Store sobre um cliente Redis (simples, sentinel ou cluster)
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore cria uma nova instância de RedisStore
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Get retorna o valor da chave ou ErrMiss quando a chave não existe
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a chave %s do Redis: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set grava o valor da chave com a validade indicada
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("erro ao gravar a chave %s no Redis: %w", key, err)
+	}
+	return nil
+}
+
+// Delete remove as chaves indicadas
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("erro ao remover chaves do Redis: %w", err)
+	}
+	return nil
+}
+
+// Config define a validade das entradas da cache
+// Valores não positivos usam os valores padrão
+type Config struct {
+	KeyPrefix   string        // Prefixo das chaves no Redis
+	TTL         time.Duration // Validade das entradas encontradas
+	NegativeTTL time.Duration // Validade das entradas de IDs e códigos inexistentes
+	TTLJitter   float64       // Fração aleatória somada à validade, para as entradas não expirarem em simultâneo
+}
+
+// DefaultConfig retorna a configuração padrão da cache dos repositórios
+func DefaultConfig() Config {
+	return Config{
+		KeyPrefix:   DefaultKeyPrefix,
+		TTL:         DefaultTTL,
+		NegativeTTL: DefaultNegativeTTL,
+		TTLJitter:   DefaultTTLJitter,
+	}
+}
+
+// withDefaults completa a configuração com os valores padrão
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.KeyPrefix == "" {
+		c.KeyPrefix = defaults.KeyPrefix
+	}
+	if c.TTL <= 0 {
+		c.TTL = defaults.TTL
+	}
+	if c.NegativeTTL <= 0 {
+		c.NegativeTTL = defaults.NegativeTTL
+	}
+	if c.TTLJitter <= 0 {
+		c.TTLJitter = defaults.TTLJitter
+	}
+	return c
+}
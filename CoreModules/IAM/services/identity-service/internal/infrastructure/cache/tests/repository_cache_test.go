@@ -0,0 +1,445 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+	"innovabiz/iam/identity-service/internal/infrastructure/cache"
+)
+
+// memoryStore implementa cache.Store em memória
+type memoryStore struct {
+	mutex   sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+	fail    bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{entries: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.fail {
+		return nil, errors.New("redis indisponível")
+	}
+	value, ok := s.entries[key]
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return value, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.fail {
+		return errors.New("redis indisponível")
+	}
+	s.entries[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.fail {
+		return errors.New("redis indisponível")
+	}
+	for _, key := range keys {
+		delete(s.entries, key)
+		delete(s.ttls, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) setFail(fail bool) {
+	s.mutex.Lock()
+	s.fail = fail
+	s.mutex.Unlock()
+}
+
+// fakeRoleRepository implementa as consultas e alterações de funções usadas pela cache
+type fakeRoleRepository struct {
+	repository.RoleRepository
+
+	mutex   sync.Mutex
+	roles   map[uuid.UUID]*model.Role
+	reads   int32
+	release chan struct{} // Quando definido, as leituras aguardam a liberação
+}
+
+func newFakeRoleRepository(roles ...*model.Role) *fakeRoleRepository {
+	repo := &fakeRoleRepository{roles: make(map[uuid.UUID]*model.Role)}
+	for _, role := range roles {
+		repo.roles[role.ID] = role
+	}
+	return repo
+}
+
+func (r *fakeRoleRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Role, error) {
+	atomic.AddInt32(&r.reads, 1)
+	if r.release != nil {
+		<-r.release
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	role, ok := r.roles[id]
+	if !ok || role.TenantID != tenantID {
+		return nil, repository.ErrRoleNotFound
+	}
+	copied := *role
+	return &copied, nil
+}
+
+func (r *fakeRoleRepository) GetByCode(ctx context.Context, tenantID uuid.UUID, code string) (*model.Role, error) {
+	atomic.AddInt32(&r.reads, 1)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, role := range r.roles {
+		if role.TenantID == tenantID && role.Code == code {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrRoleNotFound
+}
+
+func (r *fakeRoleRepository) Create(ctx context.Context, role *model.Role) error {
+	return r.Update(ctx, role)
+}
+
+func (r *fakeRoleRepository) Update(ctx context.Context, role *model.Role) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copied := *role
+	r.roles[role.ID] = &copied
+	return nil
+}
+
+func (r *fakeRoleRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.roles, id)
+	return nil
+}
+
+func (r *fakeRoleRepository) readCount() int {
+	return int(atomic.LoadInt32(&r.reads))
+}
+
+// fakePermissionRepository implementa as consultas e alterações de permissões usadas pela cache
+type fakePermissionRepository struct {
+	repository.PermissionRepository
+
+	mutex       sync.Mutex
+	permissions map[uuid.UUID]*model.Permission
+	reads       int32
+}
+
+func (r *fakePermissionRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Permission, error) {
+	atomic.AddInt32(&r.reads, 1)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	permission, ok := r.permissions[id]
+	if !ok {
+		return nil, repository.ErrPermissionNotFound
+	}
+	copied := *permission
+	return &copied, nil
+}
+
+func (r *fakePermissionRepository) Update(ctx context.Context, permission *model.Permission) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copied := *permission
+	r.permissions[permission.ID] = &copied
+	return nil
+}
+
+func (r *fakePermissionRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.permissions, id)
+	return nil
+}
+
+// recordingPublisher regista os eventos publicados
+type recordingPublisher struct {
+	events []event.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, evt event.Event) error {
+	p.events = append(p.events, evt)
+	return nil
+}
+
+// memoryBus implementa event.EventBus entregando os eventos de forma síncrona
+type memoryBus struct {
+	handlers map[string][]func(ctx context.Context, evt event.Event) error
+}
+
+func (b *memoryBus) Publish(ctx context.Context, eventType string, evt event.Event) error {
+	for _, handler := range b.handlers[eventType] {
+		if err := handler(ctx, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(eventType string, handler func(ctx context.Context, evt event.Event) error) error {
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+	return nil
+}
+
+func (b *memoryBus) Unsubscribe(eventType string, handler func(ctx context.Context, evt event.Event) error) error {
+	delete(b.handlers, eventType)
+	return nil
+}
+
+func testRole(tenantID uuid.UUID, code string) *model.Role {
+	return &model.Role{ID: uuid.New(), TenantID: tenantID, Code: code, Name: code}
+}
+
+// TestRoleCacheReadThrough verifica que as consultas repetidas por ID e por código são servidas pela cache
+func TestRoleCacheReadThrough(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	role := testRole(tenantID, "AUDITOR")
+	inner := newFakeRoleRepository(role)
+	repo := cache.NewRoleRepository(inner, newMemoryStore(), cache.DefaultConfig())
+
+	for i := 0; i < 3; i++ {
+		found, err := repo.GetByID(ctx, tenantID, role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "AUDITOR", found.Code)
+	}
+	assert.Equal(t, 1, inner.readCount())
+
+	// A consulta por código reutiliza a entidade guardada por ID depois da primeira leitura
+	for i := 0; i < 3; i++ {
+		found, err := repo.GetByCode(ctx, tenantID, "AUDITOR")
+		require.NoError(t, err)
+		assert.Equal(t, role.ID, found.ID)
+	}
+	assert.Equal(t, 2, inner.readCount())
+
+	// Cada chamador recebe a sua cópia
+	found, err := repo.GetByID(ctx, tenantID, role.ID)
+	require.NoError(t, err)
+	found.Name = "alterado"
+	again, err := repo.GetByID(ctx, tenantID, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "AUDITOR", again.Name)
+
+	// As chaves são separadas por tenant
+	_, err = repo.GetByID(ctx, uuid.New(), role.ID)
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+}
+
+// TestRoleCacheNegativeEntries verifica a cache negativa e a sua remoção quando a função é criada
+func TestRoleCacheNegativeEntries(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	inner := newFakeRoleRepository()
+	store := newMemoryStore()
+	repo := cache.NewRoleRepository(inner, store, cache.Config{NegativeTTL: 10 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetByCode(ctx, tenantID, "MISSING")
+		assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	}
+	assert.Equal(t, 1, inner.readCount())
+	for _, ttl := range store.ttls {
+		assert.Equal(t, 10*time.Second, ttl)
+	}
+
+	role := testRole(tenantID, "MISSING")
+	require.NoError(t, repo.Create(ctx, role))
+	found, err := repo.GetByCode(ctx, tenantID, "MISSING")
+	require.NoError(t, err)
+	assert.Equal(t, role.ID, found.ID)
+}
+
+// TestRoleCacheStampedeProtection verifica que as consultas concorrentes de uma chave ausente fazem uma única leitura
+func TestRoleCacheStampedeProtection(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	role := testRole(tenantID, "OPERATOR")
+	inner := newFakeRoleRepository(role)
+	inner.release = make(chan struct{})
+	repo := cache.NewRoleRepository(inner, newMemoryStore(), cache.DefaultConfig())
+
+	const callers = 20
+	var started, done sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			_, err := repo.GetByID(ctx, tenantID, role.ID)
+			errs <- err
+		}()
+	}
+	started.Wait()
+	require.Eventually(t, func() bool { return inner.readCount() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(inner.release)
+	done.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, inner.readCount())
+}
+
+// TestRoleCacheInvalidation verifica a invalidação pelas alterações do repositório e pelos eventos
+func TestRoleCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	role := testRole(tenantID, "VIEWER")
+	inner := newFakeRoleRepository(role)
+	repo := cache.NewRoleRepository(inner, newMemoryStore(), cache.DefaultConfig())
+
+	_, err := repo.GetByCode(ctx, tenantID, "VIEWER")
+	require.NoError(t, err)
+
+	// Alteração do código pelo repositório decorado: o código antigo deixa de resolver
+	role.Code = "READER"
+	require.NoError(t, repo.Update(ctx, role))
+	_, err = repo.GetByCode(ctx, tenantID, "VIEWER")
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	found, err := repo.GetByCode(ctx, tenantID, "READER")
+	require.NoError(t, err)
+	assert.Equal(t, role.ID, found.ID)
+
+	// Alteração feita por outra instância, recebida pelo barramento
+	bus := &memoryBus{handlers: make(map[string][]func(ctx context.Context, evt event.Event) error)}
+	invalidator := cache.NewInvalidator(repo, nil)
+	require.NoError(t, invalidator.Attach(bus))
+
+	role.Name = "Leitor"
+	require.NoError(t, inner.Update(ctx, role))
+	found, err = repo.GetByID(ctx, tenantID, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "VIEWER", found.Name)
+
+	require.NoError(t, bus.Publish(ctx, event.TopicRoleUpdated, &event.RoleUpdatedEvent{
+		TenantID: tenantID,
+		RoleID:   role.ID,
+		Code:     role.Code,
+	}))
+	found, err = repo.GetByID(ctx, tenantID, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Leitor", found.Name)
+
+	require.NoError(t, inner.Delete(ctx, tenantID, role.ID))
+	require.NoError(t, bus.Publish(ctx, event.TopicRoleHardDeleted, &event.RoleHardDeletedEvent{
+		TenantID: tenantID,
+		RoleID:   role.ID,
+		Code:     role.Code,
+	}))
+	_, err = repo.GetByCode(ctx, tenantID, "READER")
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+
+	require.NoError(t, invalidator.Close())
+	assert.Empty(t, bus.handlers)
+}
+
+// TestRoleCacheDelete verifica que a exclusão pelo repositório decorado invalida o ID e o código
+func TestRoleCacheDelete(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	role := testRole(tenantID, "EDITOR")
+	inner := newFakeRoleRepository(role)
+	repo := cache.NewRoleRepository(inner, newMemoryStore(), cache.DefaultConfig())
+
+	_, err := repo.GetByCode(ctx, tenantID, "EDITOR")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, tenantID, role.ID))
+	_, err = repo.GetByID(ctx, tenantID, role.ID)
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+	_, err = repo.GetByCode(ctx, tenantID, "EDITOR")
+	assert.ErrorIs(t, err, repository.ErrRoleNotFound)
+}
+
+// TestRoleCacheStoreUnavailable verifica que uma falha do Redis encaminha as consultas para o repositório
+func TestRoleCacheStoreUnavailable(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	role := testRole(tenantID, "ADMIN")
+	inner := newFakeRoleRepository(role)
+	store := newMemoryStore()
+	store.setFail(true)
+	repo := cache.NewRoleRepository(inner, store, cache.DefaultConfig())
+
+	for i := 0; i < 2; i++ {
+		found, err := repo.GetByID(ctx, tenantID, role.ID)
+		require.NoError(t, err)
+		assert.Equal(t, role.ID, found.ID)
+	}
+	assert.Equal(t, 2, inner.readCount())
+
+	role.Code = "SUPERADMIN"
+	require.NoError(t, repo.Update(ctx, role))
+
+	store.setFail(false)
+	found, err := repo.GetByID(ctx, tenantID, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "SUPERADMIN", found.Code)
+}
+
+// TestPermissionCachePublishesChanges verifica que as alterações de permissões invalidam a cache e são publicadas
+func TestPermissionCachePublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	permission := &model.Permission{ID: uuid.New(), TenantID: tenantID, Code: "users:read"}
+	inner := &fakePermissionRepository{permissions: map[uuid.UUID]*model.Permission{permission.ID: permission}}
+	publisher := &recordingPublisher{}
+	repo := cache.NewPermissionRepository(inner, newMemoryStore(), cache.DefaultConfig(), publisher)
+
+	_, err := repo.GetByID(ctx, tenantID, permission.ID)
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, tenantID, permission.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&inner.reads))
+
+	permission.Code = "users:list"
+	require.NoError(t, repo.Update(ctx, permission))
+	found, err := repo.GetByID(ctx, tenantID, permission.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "users:list", found.Code)
+
+	require.Len(t, publisher.events, 1)
+	changed, ok := publisher.events[0].(*event.PermissionChangedEvent)
+	require.True(t, ok)
+	assert.Equal(t, event.TopicPermissionUpdated, changed.GetType())
+	assert.Equal(t, permission.ID, changed.PermissionID)
+	assert.Equal(t, "users:list", changed.Code)
+
+	require.NoError(t, repo.Delete(ctx, tenantID, permission.ID))
+	_, err = repo.GetByID(ctx, tenantID, permission.ID)
+	assert.ErrorIs(t, err, repository.ErrPermissionNotFound)
+
+	require.Len(t, publisher.events, 2)
+	assert.Equal(t, event.TopicPermissionDeleted, publisher.events[1].GetType())
+}
@@ -0,0 +1,162 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Valores padrão do acesso à API do cluster onde o serviço corre
+const (
+	InClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	InClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	DefaultTimeout     = 5 * time.Second
+)
+
+// Config representa o acesso à API de um cluster Kubernetes para validar tokens
+type Config struct {
+	// APIServer é o endereço da API do cluster (ex.: https://kubernetes.default.svc)
+	APIServer string
+	// TokenFile é o ficheiro com o token do próprio serviço, relido a cada pedido por causa da rotação;
+	// a conta de serviço do IAM precisa da permissão create em tokenreviews
+	TokenFile string
+	// CAFile é a autoridade de certificação da API do cluster; vazio usa as do sistema
+	CAFile string
+	// Audiences, quando preenchidas, são exigidas nos tokens validados
+	Audiences []string
+	// Timeout limita a duração de cada TokenReview
+	Timeout time.Duration
+}
+
+// InClusterConfig retorna a configuração para o cluster onde o serviço corre,
+// a partir das variáveis e dos ficheiros montados pelo Kubernetes
+func InClusterConfig() (Config, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return Config{}, errors.New("o serviço não está a correr num cluster Kubernetes")
+	}
+	return Config{
+		APIServer: "https://" + host + ":" + port,
+		TokenFile: InClusterTokenFile,
+		CAFile:    InClusterCAFile,
+		Timeout:   DefaultTimeout,
+	}, nil
+}
+
+// tokenReview é o subconjunto do recurso authentication.k8s.io/v1 TokenReview usado aqui
+type tokenReview struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Spec       tokenReviewSpec   `json:"spec"`
+	Status     tokenReviewStatus `json:"status,omitempty"`
+}
+
+type tokenReviewSpec struct {
+	Token     string   `json:"token"`
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type tokenReviewStatus struct {
+	Authenticated bool   `json:"authenticated"`
+	Error         string `json:"error,omitempty"`
+	User          struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// TokenReviewer implementa application.KubernetesTokenReviewer com a API TokenReview do cluster
+type TokenReviewer struct {
+	config Config
+	client *http.Client
+}
+
+// NewTokenReviewer cria um novo TokenReviewer
+// Retorna erro quando o endereço da API não está configurado ou a autoridade de certificação é inválida
+func NewTokenReviewer(config Config) (*TokenReviewer, error) {
+	if config.APIServer == "" {
+		return nil, errors.New("endereço da API do cluster Kubernetes não configurado")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler autoridade de certificação do cluster: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("autoridade de certificação do cluster inválida em %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &TokenReviewer{
+		config: config,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// Review submete o token a um TokenReview e retorna o usuário autenticado pelo cluster
+func (r *TokenReviewer) Review(ctx context.Context, token string) (string, error) {
+	body, err := json.Marshal(tokenReview{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenReview",
+		Spec:       tokenReviewSpec{Token: token, Audiences: r.config.Audiences},
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao preparar TokenReview: %w", err)
+	}
+
+	url := strings.TrimSuffix(r.config.APIServer, "/") + "/apis/authentication.k8s.io/v1/tokenreviews"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("erro ao preparar TokenReview: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if r.config.TokenFile != "" {
+		serviceToken, err := os.ReadFile(r.config.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("erro ao ler token do serviço: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(serviceToken)))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("erro ao submeter TokenReview: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("TokenReview recusado pela API do cluster (%d): %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var review tokenReview
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&review); err != nil {
+		return "", fmt.Errorf("erro ao ler resposta do TokenReview: %w", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return "", fmt.Errorf("token não autenticado: %s", review.Status.Error)
+		}
+		return "", errors.New("token não autenticado")
+	}
+	return review.Status.User.Username, nil
+}
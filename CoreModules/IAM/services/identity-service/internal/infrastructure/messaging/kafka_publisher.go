@@ -0,0 +1,122 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/innovabiz/iam/observability/adapter"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/domain/event"
+)
+
+var tracer = otel.Tracer("innovabiz.iam.infrastructure.messaging")
+
+// Valor padrão do tempo máximo de escrita no Kafka
+const DefaultKafkaWriteTimeout = 10 * time.Second
+
+// KafkaConfig representa a configuração do publicador Kafka
+type KafkaConfig struct {
+	Brokers      []string
+	TopicPrefix  string
+	WriteTimeout time.Duration
+}
+
+// KafkaPublisher implementa event.Publisher publicando cada evento no tópico do seu tipo
+type KafkaPublisher struct {
+	writer      *kafka.Writer
+	topicPrefix string
+}
+
+// NewKafkaPublisher cria uma nova instância do KafkaPublisher
+func NewKafkaPublisher(config KafkaConfig) (*KafkaPublisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("nenhum broker Kafka configurado")
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultKafkaWriteTimeout
+	}
+
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			WriteTimeout:           config.WriteTimeout,
+			AllowAutoTopicCreation: false,
+		},
+		topicPrefix: config.TopicPrefix,
+	}, nil
+}
+
+// Publish serializa o evento em JSON e publica-o no tópico correspondente ao seu tipo
+// A chave da mensagem é o identificador do agregado, preservando a ordem por usuário ou função;
+// os cabeçalhos transportam o contexto de trace W3C e o baggage para os consumidores
+func (p *KafkaPublisher) Publish(ctx context.Context, evt event.Event) error {
+	topic := p.topicPrefix + evt.GetType()
+
+	ctx, span := tracer.Start(ctx, "KafkaPublisher.Publish", trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination", topic),
+	)
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento %s: %w", evt.GetType(), err)
+	}
+
+	headers := []kafka.Header{
+		{Key: "event_type", Value: []byte(evt.GetType())},
+		{Key: "event_time", Value: []byte(evt.GetTime().UTC().Format(time.RFC3339Nano))},
+	}
+	var key []byte
+	switch e := evt.(type) {
+	case *event.UserLifecycleEvent:
+		key = []byte(e.UserID.String())
+		headers = append(headers, kafka.Header{Key: "tenant_id", Value: []byte(e.TenantID.String())})
+	case event.RoleEvent:
+		key = []byte(e.GetRoleID().String())
+		headers = append(headers, kafka.Header{Key: "tenant_id", Value: []byte(e.GetTenantID().String())})
+	case *event.SecurityAnomalyDetectedEvent:
+		key = []byte(e.IncidentID.String())
+		headers = append(headers,
+			kafka.Header{Key: "tenant_id", Value: []byte(e.TenantID.String())},
+			kafka.Header{Key: "severity", Value: []byte(e.Severity)},
+		)
+	case *event.RecertificationReviewEvent:
+		key = []byte(e.CampaignID.String())
+		headers = append(headers, kafka.Header{Key: "tenant_id", Value: []byte(e.TenantID.String())})
+	case *event.RecertificationCampaignCompletedEvent:
+		key = []byte(e.CampaignID.String())
+		headers = append(headers, kafka.Header{Key: "tenant_id", Value: []byte(e.TenantID.String())})
+	}
+	headers = adapter.InjectKafkaHeaders(ctx, headers)
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     key,
+		Value:   payload,
+		Headers: headers,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return fmt.Errorf("erro ao publicar evento %s no Kafka: %w", evt.GetType(), err)
+	}
+
+	return nil
+}
+
+// Close encerra o publicador, enviando as mensagens pendentes
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
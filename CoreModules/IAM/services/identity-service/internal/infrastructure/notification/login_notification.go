@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"innovabiz/iam/identity-service/internal/application"
+)
+
+// Tempo máximo de cada pedido ao webhook quando a configuração não o indica
+const defaultWebhookTimeout = 10 * time.Second
+
+// EmailLoginNotificationSender implementa application.LoginNotificationSender no canal email
+type EmailLoginNotificationSender struct {
+	smtp *SMTPSender
+}
+
+// NewEmailLoginNotificationSender cria o remetente das notificações de login por email
+func NewEmailLoginNotificationSender(smtp *SMTPSender) *EmailLoginNotificationSender {
+	return &EmailLoginNotificationSender{smtp: smtp}
+}
+
+// Send envia a mensagem para o email do usuário
+func (s *EmailLoginNotificationSender) Send(ctx context.Context, message *application.LoginNotificationMessage) error {
+	return s.smtp.Send(ctx, message.To, message.Subject, message.Body)
+}
+
+// WebhookConfig configura a entrega das notificações a um serviço externo (gateway de SMS ou de push)
+type WebhookConfig struct {
+	URL string
+	// Secret assina o corpo de cada pedido no cabeçalho X-Innovabiz-Signature
+	Secret  []byte
+	Timeout time.Duration
+}
+
+// WebhookLoginNotificationSender implementa application.LoginNotificationSender enviando a
+// mensagem em JSON a um webhook, usado nos canais SMS e push
+//
+// A assinatura é o HMAC-SHA256, em hexadecimal, de "<timestamp>.<corpo>", com o timestamp em
+// segundos Unix no cabeçalho X-Innovabiz-Timestamp; o recetor deve rejeitar timestamps antigos
+type WebhookLoginNotificationSender struct {
+	config WebhookConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookLoginNotificationSender cria o remetente das notificações de login por webhook
+func NewWebhookLoginNotificationSender(config WebhookConfig) (*WebhookLoginNotificationSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("endereço do webhook obrigatório")
+	}
+	if len(config.Secret) == 0 {
+		return nil, errors.New("segredo de assinatura do webhook obrigatório")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultWebhookTimeout
+	}
+	return &WebhookLoginNotificationSender{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		now:    time.Now,
+	}, nil
+}
+
+// Send entrega a mensagem ao webhook; as respostas fora de 2xx são tratadas como falha
+func (s *WebhookLoginNotificationSender) Send(ctx context.Context, message *application.LoginNotificationMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar mensagem do webhook: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	mac := hmac.New(sha256.New, s.config.Secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("erro ao preparar pedido do webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Innovabiz-Timestamp", timestamp)
+	req.Header.Set("X-Innovabiz-Signature", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("X-Innovabiz-Event", "login_notification."+string(message.Kind))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao contactar o webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu com o estado %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tempo máximo de ligação ao servidor SMTP quando o contexto não tem prazo
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig configura o envio de emails por SMTP
+// Sem Username, o envio é feito sem autenticação (relé interno)
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// SkipStartTLS desativa o STARTTLS, apenas para servidores de desenvolvimento
+	SkipStartTLS bool
+}
+
+// SMTPSender implementa application.PasswordlessSender enviando os links e os códigos por email
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender cria o remetente SMTP
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" || config.Port <= 0 {
+		return nil, fmt.Errorf("servidor SMTP obrigatório")
+	}
+	if config.From == "" {
+		return nil, fmt.Errorf("remetente SMTP obrigatório")
+	}
+	return &SMTPSender{config: config}, nil
+}
+
+// SendMagicLink envia o link mágico para o email do usuário
+func (s *SMTPSender) SendMagicLink(ctx context.Context, _ uuid.UUID, email, link string, expiresAt time.Time) error {
+	body := fmt.Sprintf("Use o link abaixo para entrar. O link é válido até %s e só pode ser usado uma vez.\r\n\r\n%s\r\n\r\n"+
+		"Se não pediu este acesso, ignore esta mensagem.\r\n", expiresAt.UTC().Format("02/01/2006 15:04 MST"), link)
+	return s.send(ctx, email, "Link de acesso", body)
+}
+
+// SendOTP envia o código de uso único para o email do usuário
+func (s *SMTPSender) SendOTP(ctx context.Context, _ uuid.UUID, email, code string, expiresAt time.Time) error {
+	body := fmt.Sprintf("O seu código de acesso é %s.\r\n\r\nO código é válido até %s e só pode ser usado uma vez.\r\n\r\n"+
+		"Se não pediu este acesso, ignore esta mensagem.\r\n", code, expiresAt.UTC().Format("02/01/2006 15:04 MST"))
+	return s.send(ctx, email, "Código de acesso", body)
+}
+
+// Send envia uma mensagem em texto simples, usada por notificações operacionais (ex: alertas de compliance)
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	return s.send(ctx, to, subject, body)
+}
+
+// send entrega a mensagem ao servidor SMTP, respeitando o prazo do contexto
+func (s *SMTPSender) send(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("destinatário inválido")
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+
+	address := net.JoinHostPort(s.config.Host, fmt.Sprint(s.config.Port))
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("erro ao ligar ao servidor SMTP: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("erro ao iniciar sessão SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if !s.config.SkipStartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("erro ao ativar STARTTLS: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return fmt.Errorf("erro ao indicar o remetente: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("erro ao indicar o destinatário: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar o envio da mensagem: %w", err)
+	}
+	if _, err := writer.Write(s.message(to, subject, body)); err != nil {
+		writer.Close()
+		return fmt.Errorf("erro ao enviar a mensagem: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("erro ao concluir o envio da mensagem: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message monta a mensagem em texto simples com os cabeçalhos obrigatórios
+func (s *SMTPSender) message(to, subject, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(body)
+	return msg.Bytes()
+}
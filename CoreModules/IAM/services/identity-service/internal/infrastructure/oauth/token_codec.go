@@ -0,0 +1,183 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Config representa a configuração do codec dos tokens da troca
+type Config struct {
+	// Secret é a chave HMAC partilhada com os serviços que validam os tokens (JWT_SECRET)
+	// Com HS256, quem valida os tokens também os pode emitir: ver middleware.DelegatedTokenConfig
+	Secret []byte
+	// Issuer, quando preenchido, é exigido na claim iss dos tokens lidos
+	Issuer string
+	// Leeway é a diferença de relógio tolerada na validação da validade dos tokens
+	Leeway time.Duration
+}
+
+// jwtClaims são as claims JWT dos tokens da troca, com os nomes usados pelo AuthMiddleware
+// A claim scope é um ponteiro para distinguir os tokens sem escopos dos tokens com escopo vazio
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	TenantID  string            `json:"tid,omitempty"`
+	Username  string            `json:"preferred_username,omitempty"`
+	Market    string            `json:"market,omitempty"`
+	MFALevel  string            `json:"mfa_level,omitempty"`
+	AuthTime  *jwt.NumericDate  `json:"auth_time,omitempty"`
+	SessionID string            `json:"sid,omitempty"`
+	ClientID  string            `json:"client_id,omitempty"`
+	Scope     *string           `json:"scope,omitempty"`
+	Actor     *model.ActorClaim `json:"act,omitempty"`
+}
+
+// TokenCodec implementa application.TokenExchangeCodec com JWT assinados com HS256
+type TokenCodec struct {
+	secret []byte
+	issuer string
+	leeway time.Duration
+}
+
+// NewTokenCodec cria um novo codec
+// Retorna erro quando a chave não está configurada
+func NewTokenCodec(config Config) (*TokenCodec, error) {
+	if len(config.Secret) == 0 {
+		return nil, errors.New("chave de assinatura dos tokens não configurada")
+	}
+	return &TokenCodec{
+		secret: config.Secret,
+		issuer: config.Issuer,
+		leeway: config.Leeway,
+	}, nil
+}
+
+// Parse valida a assinatura, o emissor e a validade do token e retorna as suas claims
+// Os tokens sem validade (exp) não são aceites
+func (c *TokenCodec) Parse(token string) (*model.TokenClaims, error) {
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithLeeway(c.leeway)}
+	if c.issuer != "" {
+		options = append(options, jwt.WithIssuer(c.issuer))
+	}
+
+	var parsed jwtClaims
+	if _, err := jwt.ParseWithClaims(token, &parsed, func(*jwt.Token) (interface{}, error) {
+		return c.secret, nil
+	}, options...); err != nil {
+		return nil, fmt.Errorf("token inválido: %w", err)
+	}
+	if parsed.ExpiresAt == nil {
+		return nil, errors.New("token sem validade")
+	}
+
+	claims := &model.TokenClaims{
+		TokenID:   parsed.ID,
+		Issuer:    parsed.Issuer,
+		Subject:   parsed.Subject,
+		TenantID:  parsed.TenantID,
+		Audience:  parsed.Audience,
+		ClientID:  parsed.ClientID,
+		Username:  parsed.Username,
+		Market:    parsed.Market,
+		MFALevel:  parsed.MFALevel,
+		SessionID: parsed.SessionID,
+		ExpiresAt: parsed.ExpiresAt.Time,
+		Actor:     parsed.Actor,
+	}
+	if parsed.IssuedAt != nil {
+		claims.IssuedAt = parsed.IssuedAt.Time
+	}
+	if parsed.AuthTime != nil {
+		authTime := parsed.AuthTime.Time
+		claims.AuthTime = &authTime
+	}
+	if parsed.Scope != nil {
+		claims.Scopes = strings.Fields(*parsed.Scope)
+	}
+
+	// As claims personalizadas são as que não pertencem ao conjunto reservado; a assinatura já foi validada
+	var all jwt.MapClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &all); err != nil {
+		return nil, fmt.Errorf("token inválido: %w", err)
+	}
+	for name, value := range all {
+		if model.IsReservedTokenClaim(name) {
+			continue
+		}
+		if claims.CustomClaims == nil {
+			claims.CustomClaims = make(map[string]interface{})
+		}
+		claims.CustomClaims[name] = value
+	}
+	return claims, nil
+}
+
+// Sign assina as claims de um token emitido pela troca
+func (c *TokenCodec) Sign(claims *model.TokenClaims) (string, error) {
+	token := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        claims.TokenID,
+			Issuer:    claims.Issuer,
+			Subject:   claims.Subject,
+			Audience:  claims.Audience,
+			IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+			NotBefore: jwt.NewNumericDate(claims.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+		},
+		TenantID:  claims.TenantID,
+		Username:  claims.Username,
+		Market:    claims.Market,
+		MFALevel:  claims.MFALevel,
+		SessionID: claims.SessionID,
+		ClientID:  claims.ClientID,
+		Actor:     claims.Actor,
+	}
+	if claims.AuthTime != nil {
+		token.AuthTime = jwt.NewNumericDate(*claims.AuthTime)
+	}
+	if claims.HasScopeClaim() {
+		scope := strings.Join(claims.Scopes, " ")
+		token.Scope = &scope
+	}
+
+	var signing jwt.Claims = token
+	if len(claims.CustomClaims) > 0 {
+		merged, err := mergeCustomClaims(token, claims.CustomClaims)
+		if err != nil {
+			return "", err
+		}
+		signing = merged
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, signing).SignedString(c.secret)
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar token: %w", err)
+	}
+	return signed, nil
+}
+
+// mergeCustomClaims junta as claims personalizadas às claims do token
+// As claims reservadas não são substituídas, mesmo que constem das personalizadas
+func mergeCustomClaims(token jwtClaims, custom map[string]interface{}) (jwt.MapClaims, error) {
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar claims do token: %w", err)
+	}
+	var merged jwt.MapClaims
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return nil, fmt.Errorf("erro ao serializar claims do token: %w", err)
+	}
+	for name, value := range custom {
+		if _, exists := merged[name]; exists || model.IsReservedTokenClaim(name) {
+			continue
+		}
+		merged[name] = value
+	}
+	return merged, nil
+}
@@ -0,0 +1,430 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de pedidos de acesso
+const accessRequestColumns = `
+	id, tenant_id, requester_id, target_type, target_id, justification,
+	requested_expires_at, status, decided_by, COALESCE(decision_reason, ''),
+	grant_expires_at, created_at, decided_at
+`
+
+// AccessRequestRepository implementa a interface repository.AccessRequestRepository usando PostgreSQL
+type AccessRequestRepository struct {
+	db *DB
+}
+
+// NewAccessRequestRepository cria uma nova instância do AccessRequestRepository
+func NewAccessRequestRepository(db *DB) *AccessRequestRepository {
+	return &AccessRequestRepository{db: db}
+}
+
+// Create grava um novo pedido pendente
+func (r *AccessRequestRepository) Create(ctx context.Context, req *model.AccessRequest) error {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("access_request.id", req.ID.String()),
+		attribute.String("tenant.id", req.TenantID.String()),
+	)
+
+	query := `
+		INSERT INTO access_requests (
+			id, tenant_id, requester_id, target_type, target_id, justification,
+			requested_expires_at, status, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			req.ID, req.TenantID, req.RequesterID, string(req.TargetType), req.TargetID, req.Justification,
+			req.RequestedExpiresAt, string(req.Status), req.CreatedAt,
+		)
+		if err != nil {
+			// O índice único parcial admite um único pedido pendente por requerente e destino
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrDuplicateAccessRequest
+			}
+			return fmt.Errorf("erro ao inserir pedido de acesso: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Get recupera um pedido pelo ID
+func (r *AccessRequestRepository) Get(ctx context.Context, tenantID, requestID uuid.UUID) (*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("access_request.id", requestID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + accessRequestColumns + `
+		FROM access_requests
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var req *model.AccessRequest
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		req, err = scanAccessRequest(tx.QueryRow(ctx, query, tenantID, requestID))
+		if err == pgx.ErrNoRows {
+			return model.ErrAccessRequestNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar pedido de acesso: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// List recupera os pedidos do tenant que satisfazem o filtro
+func (r *AccessRequestRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.AccessRequestFilter) ([]*model.AccessRequest, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.List")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(filter.Status)),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", string(filter.Status))
+	}
+	if filter.RequesterID != nil {
+		addCondition("requester_id = $%d", *filter.RequesterID)
+	}
+	if filter.RolesOnly {
+		conditions = append(conditions, "target_type = 'role'")
+	}
+	if len(filter.RoleIDs) > 0 {
+		addCondition("(target_type <> 'role' OR target_id = ANY($%d))", filter.RoleIDs)
+	}
+	if filter.DecidedSince != nil {
+		addCondition("decided_at >= $%d", *filter.DecidedSince)
+	}
+
+	query := `SELECT ` + accessRequestColumns + `
+		FROM access_requests
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at ASC
+	`
+
+	var requests []*model.AccessRequest
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar pedidos de acesso: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			req, err := scanAccessRequest(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler pedido de acesso: %w", err)
+			}
+			requests = append(requests, req)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// SaveDecision grava o estado e a decisão do pedido
+func (r *AccessRequestRepository) SaveDecision(ctx context.Context, req *model.AccessRequest, expected model.AccessRequestStatus) error {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.SaveDecision")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("access_request.id", req.ID.String()),
+		attribute.String("tenant.id", req.TenantID.String()),
+		attribute.String("access_request.status", string(req.Status)),
+	)
+
+	// A condição sobre o estado esperado impede decisões concorrentes sobre o mesmo pedido
+	query := `
+		UPDATE access_requests
+		SET status = $3, decided_by = $4, decision_reason = NULLIF($5, ''),
+			grant_expires_at = $6, decided_at = $7
+		WHERE tenant_id = $1 AND id = $2 AND status = $8
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			req.TenantID, req.ID, string(req.Status), req.DecidedBy, req.DecisionReason,
+			req.GrantExpiresAt, req.DecidedAt, string(expected),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar pedido de acesso: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: pedido %s não está em %s", model.ErrAccessRequestConflict, req.ID, expected)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// PermissionExists verifica se a permissão existe e está ativa no tenant
+func (r *AccessRequestRepository) PermissionExists(ctx context.Context, tenantID, permissionID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.PermissionExists")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("permission.id", permissionID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM permissions
+			WHERE tenant_id = $1 AND id = $2 AND is_active = true AND deleted_at IS NULL
+		)
+	`
+
+	var exists bool
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, tenantID, permissionID).Scan(&exists); err != nil {
+			return fmt.Errorf("erro ao verificar permissão: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GrantPermission concede uma permissão diretamente ao usuário
+func (r *AccessRequestRepository) GrantPermission(ctx context.Context, tenantID, userID, permissionID uuid.UUID, expiresAt *time.Time, grantedBy, requestID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.GrantPermission")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("permission.id", permissionID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `
+		INSERT INTO user_permissions (
+			tenant_id, user_id, permission_id, expires_at, granted_by, access_request_id, granted_at
+		) VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		ON CONFLICT (tenant_id, user_id, permission_id) DO UPDATE SET
+			expires_at = EXCLUDED.expires_at,
+			granted_by = EXCLUDED.granted_by,
+			access_request_id = EXCLUDED.access_request_id,
+			granted_at = EXCLUDED.granted_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, tenantID, userID, permissionID, expiresAt, grantedBy, requestID)
+		if err != nil {
+			return fmt.Errorf("erro ao conceder permissão ao usuário: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListApprovers recupera os aprovadores do tenant, ou apenas os do usuário quando indicado
+func (r *AccessRequestRepository) ListApprovers(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.AccessRequestApprover, error) {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.ListApprovers")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT id, tenant_id, user_id, kind, role_id, created_by, created_at
+		FROM access_request_approvers
+		WHERE tenant_id = $1 AND ($2::UUID IS NULL OR user_id = $2)
+		ORDER BY created_at ASC
+	`
+
+	var approvers []*model.AccessRequestApprover
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar aprovadores: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				approver model.AccessRequestApprover
+				kind     string
+			)
+			if err := rows.Scan(
+				&approver.ID, &approver.TenantID, &approver.UserID, &kind, &approver.RoleID,
+				&approver.CreatedBy, &approver.CreatedAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler aprovador: %w", err)
+			}
+
+			approver.Kind = model.AccessApproverKind(kind)
+			approvers = append(approvers, &approver)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return approvers, nil
+}
+
+// SaveApprover grava um aprovador; uma atribuição repetida é ignorada
+func (r *AccessRequestRepository) SaveApprover(ctx context.Context, approver *model.AccessRequestApprover) error {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.SaveApprover")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", approver.UserID.String()),
+		attribute.String("tenant.id", approver.TenantID.String()),
+		attribute.String("approver.kind", string(approver.Kind)),
+	)
+
+	query := `
+		INSERT INTO access_request_approvers (
+			id, tenant_id, user_id, kind, role_id, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			approver.ID, approver.TenantID, approver.UserID, string(approver.Kind), approver.RoleID,
+			approver.CreatedBy, approver.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir aprovador: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteApprover remove um aprovador
+func (r *AccessRequestRepository) DeleteApprover(ctx context.Context, tenantID, approverID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AccessRequestRepository.DeleteApprover")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("approver.id", approverID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM access_request_approvers WHERE tenant_id = $1 AND id = $2`, tenantID, approverID)
+		if err != nil {
+			return fmt.Errorf("erro ao remover aprovador: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrAccessApproverNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanAccessRequest lê as colunas de accessRequestColumns
+func scanAccessRequest(row pgx.Row) (*model.AccessRequest, error) {
+	var (
+		req                model.AccessRequest
+		targetType, status string
+	)
+	err := row.Scan(
+		&req.ID, &req.TenantID, &req.RequesterID, &targetType, &req.TargetID, &req.Justification,
+		&req.RequestedExpiresAt, &status, &req.DecidedBy, &req.DecisionReason,
+		&req.GrantExpiresAt, &req.CreatedAt, &req.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.TargetType = model.AccessRequestTarget(targetType)
+	req.Status = model.AccessRequestStatus(status)
+	return &req, nil
+}
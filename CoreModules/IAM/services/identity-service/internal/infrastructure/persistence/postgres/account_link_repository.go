@@ -0,0 +1,570 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório da ligação de contas
+const accountLinkCandidateColumns = `
+	id, tenant_id, user_id, provider_id, subject, email, status, detected_at, expires_at, resolved_at
+`
+
+const accountLinkEventColumns = `
+	id, tenant_id, user_id, actor_id, action, provider_id, subject, candidate_id, identity_id,
+	authenticated_at, COALESCE(ip_address, ''), occurred_at
+`
+
+// AccountLinkRepository implementa a interface repository.AccountLinkRepository usando PostgreSQL
+type AccountLinkRepository struct {
+	db *DB
+}
+
+// NewAccountLinkRepository cria uma nova instância do AccountLinkRepository
+func NewAccountLinkRepository(db *DB) *AccountLinkRepository {
+	return &AccountLinkRepository{db: db}
+}
+
+// FindUserByVerifiedEmail recupera o usuário do tenant com o email indicado, desde que verificado
+func (r *AccountLinkRepository) FindUserByVerifiedEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.FindUserByVerifiedEmail")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND lower(email) = lower($2) AND email_verified AND deleted_at IS NULL
+	`
+
+	var user *model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		user, err = scanLifecycleUser(tx.QueryRow(ctx, query, tenantID, email))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuário pelo email: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListFederatedIdentities recupera as identidades federadas do usuário
+func (r *AccountLinkRepository) ListFederatedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.FederatedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.ListFederatedIdentities")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + federatedIdentityColumns + `
+		FROM federated_identities
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY created_at
+	`
+
+	var identities []*model.FederatedIdentity
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar identidades federadas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			identity, err := scanFederatedIdentity(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler identidade federada: %w", err)
+			}
+			identities = append(identities, identity)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// ListLinkedIdentities recupera a senha local, quando existe, e as identidades federadas do usuário
+func (r *AccountLinkRepository) ListLinkedIdentities(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.LinkedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.ListLinkedIdentities")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	passwordQuery := `
+		SELECT c.id, c.created_at
+		FROM user_credentials c
+		JOIN users u ON u.id = c.user_id
+		WHERE u.tenant_id = $1 AND c.user_id = $2 AND c.provider = $3 AND c.password_hash IS NOT NULL
+		ORDER BY c.created_at
+		LIMIT 1
+	`
+
+	federatedQuery := `
+		SELECT f.id, f.provider_id, p.name, f.subject, f.created_at, f.last_login_at
+		FROM federated_identities f
+		JOIN saml_identity_providers p ON p.id = f.provider_id
+		WHERE f.tenant_id = $1 AND f.user_id = $2
+		ORDER BY f.created_at
+	`
+
+	var identities []*model.LinkedIdentity
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		password := model.LinkedIdentity{Kind: model.LinkedIdentityPassword}
+		err := tx.QueryRow(ctx, passwordQuery, tenantID, userID, string(model.AuthProviderLocal)).Scan(&password.ID, &password.LinkedAt)
+		switch {
+		case err == nil:
+			identities = append(identities, &password)
+		case err != pgx.ErrNoRows:
+			return fmt.Errorf("erro ao consultar credencial local: %w", err)
+		}
+
+		rows, err := tx.Query(ctx, federatedQuery, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar identidades federadas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				identity   = model.LinkedIdentity{Kind: model.LinkedIdentitySAML}
+				providerID uuid.UUID
+			)
+			if err := rows.Scan(
+				&identity.ID, &providerID, &identity.ProviderName, &identity.Subject, &identity.LinkedAt,
+				&identity.LastLoginAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler identidade federada: %w", err)
+			}
+			identity.ProviderID = &providerID
+			identities = append(identities, &identity)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// SaveCandidate grava um candidato à ligação e o respetivo evento na mesma transação
+func (r *AccountLinkRepository) SaveCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.SaveCandidate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", candidate.TenantID.String()),
+		attribute.String("account_link_candidate.id", candidate.ID.String()),
+		attribute.String("saml_provider.id", candidate.ProviderID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE account_link_candidates
+			SET status = $4, resolved_at = $5
+			WHERE tenant_id = $1 AND provider_id = $2 AND subject = $3 AND status = 'pending'
+		`,
+			candidate.TenantID, candidate.ProviderID, candidate.Subject,
+			string(model.AccountLinkCandidateExpired), candidate.DetectedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao expirar candidato anterior à ligação de contas: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO account_link_candidates (
+				id, tenant_id, user_id, provider_id, subject, email, status, detected_at, expires_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`,
+			candidate.ID, candidate.TenantID, candidate.UserID, candidate.ProviderID, candidate.Subject,
+			candidate.Email, string(candidate.Status), candidate.DetectedAt, candidate.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir candidato à ligação de contas: %w", err)
+		}
+
+		return insertAccountLinkEvent(ctx, tx, event)
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCandidate recupera um candidato à ligação do tenant
+func (r *AccountLinkRepository) GetCandidate(ctx context.Context, tenantID, candidateID uuid.UUID) (*model.AccountLinkCandidate, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.GetCandidate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("account_link_candidate.id", candidateID.String()),
+	)
+
+	query := `SELECT ` + accountLinkCandidateColumns + `
+		FROM account_link_candidates
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var candidate *model.AccountLinkCandidate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		candidate, err = scanAccountLinkCandidate(tx.QueryRow(ctx, query, tenantID, candidateID))
+		if err == pgx.ErrNoRows {
+			return model.ErrAccountLinkCandidateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler candidato à ligação de contas: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return candidate, nil
+}
+
+// ListOpenCandidates recupera os candidatos pendentes e não expirados do usuário, mais recentes primeiro
+func (r *AccountLinkRepository) ListOpenCandidates(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.AccountLinkCandidate, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.ListOpenCandidates")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + accountLinkCandidateColumns + `
+		FROM account_link_candidates
+		WHERE tenant_id = $1 AND user_id = $2 AND status = 'pending' AND expires_at > $3
+		ORDER BY detected_at DESC
+	`
+
+	var candidates []*model.AccountLinkCandidate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID, now)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar candidatos à ligação de contas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			candidate, err := scanAccountLinkCandidate(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler candidato à ligação de contas: %w", err)
+			}
+			candidates = append(candidates, candidate)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// LinkCandidate marca o candidato como ligado, cria a identidade federada e grava o evento numa única transação
+func (r *AccountLinkRepository) LinkCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, identity *model.FederatedIdentity, event *model.AccountLinkEvent) error {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.LinkCandidate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", candidate.TenantID.String()),
+		attribute.String("account_link_candidate.id", candidate.ID.String()),
+		attribute.String("user.id", candidate.UserID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := resolveAccountLinkCandidate(ctx, tx, candidate); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO federated_identities (
+				id, tenant_id, provider_id, subject, user_id, granted_role_ids, created_at, last_login_at
+			) VALUES ($1, $2, $3, $4, $5, $6::UUID[], $7, $8)
+		`,
+			identity.ID, identity.TenantID, identity.ProviderID, identity.Subject, identity.UserID,
+			uuidStrings(identity.GrantedRoleIDs), identity.CreatedAt, identity.LastLoginAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrIdentityAlreadyLinked
+			}
+			return fmt.Errorf("erro ao inserir identidade federada: %w", err)
+		}
+
+		return insertAccountLinkEvent(ctx, tx, event)
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DismissCandidate marca o candidato como recusado e grava o evento na mesma transação
+func (r *AccountLinkRepository) DismissCandidate(ctx context.Context, candidate *model.AccountLinkCandidate, event *model.AccountLinkEvent) error {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.DismissCandidate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", candidate.TenantID.String()),
+		attribute.String("account_link_candidate.id", candidate.ID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := resolveAccountLinkCandidate(ctx, tx, candidate); err != nil {
+			return err
+		}
+		return insertAccountLinkEvent(ctx, tx, event)
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UnlinkIdentity remove a identidade federada do usuário e grava o evento na mesma transação
+// O usuário é bloqueado durante a transação para que duas remoções concorrentes não o deixem
+// sem nenhum método de login. As credenciais federadas criadas no provisionamento da identidade
+// são removidas com ela
+func (r *AccountLinkRepository) UnlinkIdentity(ctx context.Context, tenantID, userID, identityID uuid.UUID, event *model.AccountLinkEvent) (*model.FederatedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.UnlinkIdentity")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("federated_identity.id", identityID.String()),
+	)
+
+	identityQuery := `SELECT ` + federatedIdentityColumns + `
+		FROM federated_identities
+		WHERE tenant_id = $1 AND user_id = $2 AND id = $3
+	`
+
+	remainingQuery := `
+		SELECT
+			EXISTS (
+				SELECT 1 FROM user_credentials
+				WHERE user_id = $2 AND provider = $4 AND password_hash IS NOT NULL
+			),
+			EXISTS (
+				SELECT 1 FROM federated_identities
+				WHERE tenant_id = $1 AND user_id = $2 AND id <> $3
+			)
+	`
+
+	var identity *model.FederatedIdentity
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var locked uuid.UUID
+		err := tx.QueryRow(ctx, `SELECT id FROM users WHERE tenant_id = $1 AND id = $2 FOR UPDATE`, tenantID, userID).Scan(&locked)
+		if err == pgx.ErrNoRows {
+			return model.ErrLinkedIdentityNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao bloquear usuário: %w", err)
+		}
+
+		identity, err = scanFederatedIdentity(tx.QueryRow(ctx, identityQuery, tenantID, userID, identityID))
+		if err == pgx.ErrNoRows {
+			return model.ErrLinkedIdentityNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler identidade federada: %w", err)
+		}
+
+		var hasPassword, hasOtherIdentity bool
+		if err := tx.QueryRow(ctx, remainingQuery, tenantID, userID, identityID, string(model.AuthProviderLocal)).
+			Scan(&hasPassword, &hasOtherIdentity); err != nil {
+			return fmt.Errorf("erro ao verificar métodos de login restantes: %w", err)
+		}
+		if !hasPassword && !hasOtherIdentity {
+			return model.ErrLastLinkedIdentity
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM federated_identities WHERE id = $1`, identity.ID); err != nil {
+			return fmt.Errorf("erro ao remover identidade federada: %w", err)
+		}
+		_, err = tx.Exec(ctx, `
+			DELETE FROM user_credentials
+			WHERE user_id = $1 AND provider = $2 AND provider_user_id = $3
+		`, userID, string(model.AuthProviderCustomSAML), identity.Subject)
+		if err != nil {
+			return fmt.Errorf("erro ao remover credenciais federadas: %w", err)
+		}
+
+		event.ProviderID = identity.ProviderID
+		event.Subject = identity.Subject
+		return insertAccountLinkEvent(ctx, tx, event)
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// ListEvents recupera o registo de auditoria do tenant, mais recentes primeiro
+func (r *AccountLinkRepository) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.AccountLinkEventFilter) ([]*model.AccountLinkEvent, error) {
+	ctx, span := tracer.Start(ctx, "AccountLinkRepository.ListEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("account_link.action", string(filter.Action)),
+	)
+
+	query := `SELECT ` + accountLinkEventColumns + `
+		FROM account_link_events
+		WHERE tenant_id = $1
+			AND ($2::UUID IS NULL OR user_id = $2)
+			AND ($3 = '' OR action = $3)
+			AND ($4::TIMESTAMPTZ IS NULL OR occurred_at >= $4)
+		ORDER BY occurred_at DESC
+		LIMIT $5
+	`
+
+	var events []*model.AccountLinkEvent
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, filter.UserID, string(filter.Action), filter.Since, filter.Limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar registo de ligação de contas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				event  model.AccountLinkEvent
+				action string
+			)
+			if err := rows.Scan(
+				&event.ID, &event.TenantID, &event.UserID, &event.ActorID, &action, &event.ProviderID,
+				&event.Subject, &event.CandidateID, &event.IdentityID, &event.AuthenticatedAt, &event.IPAddress,
+				&event.OccurredAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler registo de ligação de contas: %w", err)
+			}
+			event.Action = model.AccountLinkAction(action)
+			events = append(events, &event)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// resolveAccountLinkCandidate grava o estado final de um candidato que ainda está pendente
+func resolveAccountLinkCandidate(ctx context.Context, tx pgx.Tx, candidate *model.AccountLinkCandidate) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE account_link_candidates
+		SET status = $3, resolved_at = $4
+		WHERE tenant_id = $1 AND id = $2 AND status = 'pending'
+	`, candidate.TenantID, candidate.ID, string(candidate.Status), candidate.ResolvedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar candidato à ligação de contas: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrAccountLinkCandidateClosed
+	}
+	return nil
+}
+
+// insertAccountLinkEvent acrescenta uma operação ao registo de auditoria da ligação de contas
+func insertAccountLinkEvent(ctx context.Context, tx pgx.Tx, event *model.AccountLinkEvent) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO account_link_events (
+			id, tenant_id, user_id, actor_id, action, provider_id, subject, candidate_id, identity_id,
+			authenticated_at, ip_address, occurred_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
+	`,
+		event.ID, event.TenantID, event.UserID, event.ActorID, string(event.Action), event.ProviderID,
+		event.Subject, event.CandidateID, event.IdentityID, event.AuthenticatedAt, event.IPAddress,
+		event.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registar operação de ligação de contas: %w", err)
+	}
+	return nil
+}
+
+// scanAccountLinkCandidate lê as colunas de accountLinkCandidateColumns
+func scanAccountLinkCandidate(row pgx.Row) (*model.AccountLinkCandidate, error) {
+	var (
+		candidate model.AccountLinkCandidate
+		status    string
+	)
+	err := row.Scan(
+		&candidate.ID, &candidate.TenantID, &candidate.UserID, &candidate.ProviderID, &candidate.Subject,
+		&candidate.Email, &status, &candidate.DetectedAt, &candidate.ExpiresAt, &candidate.ResolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	candidate.Status = model.AccountLinkCandidateStatus(status)
+	return &candidate, nil
+}
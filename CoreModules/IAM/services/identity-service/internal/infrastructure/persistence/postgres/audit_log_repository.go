@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório dos registos de auditoria
+const auditLogColumns = `
+	id, tenant_id, event_type, entity_type, entity_id, user_id, severity, COALESCE(market, ''),
+	COALESCE(details, ''), COALESCE(ip_address, ''), COALESCE(user_agent, ''), old_value, new_value, metadata, created_at
+`
+
+// AuditLogRepository implementa a interface repository.AuditLogRepository usando PostgreSQL
+type AuditLogRepository struct {
+	db *DB
+}
+
+// NewAuditLogRepository cria uma nova instância do AuditLogRepository
+func NewAuditLogRepository(db *DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Search recupera os registos de auditoria do tenant que satisfazem o filtro
+func (r *AuditLogRepository) Search(ctx context.Context, tenantID uuid.UUID, filter model.AuditLogFilter) ([]*model.AuditLogEntry, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogRepository.Search")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Bool("audit_log.full_text", filter.Query != ""),
+		attribute.Int("audit_log.limit", filter.Limit),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	addCondition := func(format string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(format, placeholders...))
+	}
+
+	if filter.Query != "" {
+		addCondition("search_vector @@ websearch_to_tsquery('simple', $%d)", filter.Query)
+	}
+	if filter.ActorID != nil {
+		addCondition("user_id = $%d", *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		addCondition("entity_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		addCondition("entity_id = $%d", *filter.TargetID)
+	}
+	if len(filter.EventTypes) > 0 {
+		addCondition("event_type = ANY($%d)", filter.EventTypes)
+	}
+	if len(filter.Severities) > 0 {
+		severities := make([]string, len(filter.Severities))
+		for i, severity := range filter.Severities {
+			severities[i] = string(severity)
+		}
+		addCondition("severity = ANY($%d)", severities)
+	}
+	if filter.Market != "" {
+		addCondition("market = $%d", filter.Market)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.Cursor != nil {
+		addCondition("(created_at, id) < ($%d, $%d)", filter.Cursor.CreatedAt, filter.Cursor.ID)
+	}
+
+	// A ordem (created_at, id) decrescente usa o índice idx_audit_logs_tenant_created
+	args = append(args, filter.Limit)
+	query := `SELECT ` + auditLogColumns + `
+		FROM audit_logs
+		WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, len(args))
+
+	var entries []*model.AuditLogEntry
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar registos de auditoria: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			entry, err := scanAuditLogEntry(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler registo de auditoria: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// scanAuditLogEntry lê um registo de auditoria na ordem de auditLogColumns
+func scanAuditLogEntry(row pgx.Row) (*model.AuditLogEntry, error) {
+	var entry model.AuditLogEntry
+	var severity string
+	var oldValue, newValue, metadata []byte
+	if err := row.Scan(
+		&entry.ID, &entry.TenantID, &entry.EventType, &entry.EntityType, &entry.EntityID, &entry.UserID,
+		&severity, &entry.Market, &entry.Details, &entry.IPAddress, &entry.UserAgent,
+		&oldValue, &newValue, &metadata, &entry.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	entry.Severity = model.AuditSeverity(severity)
+	entry.OldValue = oldValue
+	entry.NewValue = newValue
+	entry.Metadata = metadata
+	return &entry, nil
+}
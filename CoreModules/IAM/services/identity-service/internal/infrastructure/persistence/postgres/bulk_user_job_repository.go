@@ -0,0 +1,483 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de tarefas de operação em massa
+const bulkUserJobColumns = `
+	id, tenant_id, operation, status, total, processed, succeeded, skipped, failed,
+	COALESCE(error, ''), requested_by, attempts, version, created_at, updated_at, completed_at
+`
+
+// Colunas lidas dos itens das tarefas
+const bulkUserJobItemColumns = `
+	job_id, tenant_id, line, user_id, role_id, COALESCE(reason, ''), expires_at, status,
+	COALESCE(code, ''), COALESCE(message, ''), attempts, processed_at
+`
+
+// Número máximo de tarefas devolvidas quando o limite não é indicado
+const defaultBulkUserJobListLimit = 50
+
+// Entidade dos registos de auditoria gravados por usuário afetado
+const bulkUserAuditEntityType = "users"
+
+// BulkUserJobRepository implementa a interface repository.BulkUserJobRepository usando PostgreSQL
+type BulkUserJobRepository struct {
+	db *DB
+}
+
+// NewBulkUserJobRepository cria uma nova instância do BulkUserJobRepository
+func NewBulkUserJobRepository(db *DB) *BulkUserJobRepository {
+	return &BulkUserJobRepository{db: db}
+}
+
+// Create grava uma nova tarefa e os seus itens numa transação
+// Os itens são copiados com o protocolo COPY, pelo que tarefas com milhares de usuários
+// são gravadas numa única ida à base de dados
+func (r *BulkUserJobRepository) Create(ctx context.Context, job *model.BulkUserJob, items []*model.BulkUserJobItem) error {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", job.ID.String()),
+		attribute.String("tenant.id", job.TenantID.String()),
+		attribute.Int("bulk_user_job.total", job.Total),
+	)
+
+	query := `
+		INSERT INTO bulk_user_jobs (
+			id, tenant_id, operation, status, total, processed, succeeded, skipped, failed,
+			requested_by, attempts, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			job.ID, job.TenantID, string(job.Operation), string(job.Status), job.Total, job.Processed,
+			job.Succeeded, job.Skipped, job.Failed, job.RequestedBy, job.Attempts, job.Version,
+			job.CreatedAt, job.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir tarefa de operação em massa: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"bulk_user_job_items"},
+			[]string{"job_id", "tenant_id", "line", "user_id", "role_id", "reason", "expires_at", "status", "attempts"},
+			pgx.CopyFromSlice(len(items), func(i int) ([]interface{}, error) {
+				item := items[i]
+				return []interface{}{
+					item.JobID, item.TenantID, item.Line, item.UserID, item.RoleID, nullIfEmpty(item.Reason),
+					item.ExpiresAt, string(item.Status), item.Attempts,
+				}, nil
+			}),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir itens da tarefa de operação em massa: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Get recupera uma tarefa pelo ID
+func (r *BulkUserJobRepository) Get(ctx context.Context, tenantID, jobID uuid.UUID) (*model.BulkUserJob, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", jobID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + bulkUserJobColumns + `
+		FROM bulk_user_jobs
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var job *model.BulkUserJob
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		job, err = scanBulkUserJob(tx.QueryRow(ctx, query, tenantID, jobID))
+		if err == pgx.ErrNoRows {
+			return model.ErrBulkUserJobNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar tarefa de operação em massa: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// List recupera as tarefas do tenant, da mais recente para a mais antiga
+func (r *BulkUserJobRepository) List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.BulkUserJob, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	if limit <= 0 {
+		limit = defaultBulkUserJobListLimit
+	}
+	query := `SELECT ` + bulkUserJobColumns + `
+		FROM bulk_user_jobs
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	jobs, err := r.queryJobs(ctx, query, tenantID, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ListActive recupera as tarefas pendentes ou em execução de todos os tenants
+func (r *BulkUserJobRepository) ListActive(ctx context.Context, limit int) ([]*model.BulkUserJob, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.ListActive")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultBulkUserJobListLimit
+	}
+	query := `SELECT ` + bulkUserJobColumns + `
+		FROM bulk_user_jobs
+		WHERE status IN ('pending', 'running')
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	jobs, err := r.queryJobs(ctx, query, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ListItems recupera até limit itens da tarefa com linha posterior a afterLine, ordenados pela linha
+func (r *BulkUserJobRepository) ListItems(
+	ctx context.Context,
+	tenantID, jobID uuid.UUID,
+	status model.BulkUserItemStatus,
+	afterLine, limit int,
+) ([]*model.BulkUserJobItem, error) {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.ListItems")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", jobID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("bulk_user_job.item_status", string(status)),
+	)
+
+	query := `SELECT ` + bulkUserJobItemColumns + `
+		FROM bulk_user_job_items
+		WHERE tenant_id = $1 AND job_id = $2 AND line > $3 AND ($4 = '' OR status = $4)
+		ORDER BY line
+		LIMIT $5
+	`
+
+	var items []*model.BulkUserJobItem
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, jobID, afterLine, string(status), limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar itens da tarefa de operação em massa: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				item       model.BulkUserJobItem
+				itemStatus string
+			)
+			err := rows.Scan(
+				&item.JobID, &item.TenantID, &item.Line, &item.UserID, &item.RoleID, &item.Reason, &item.ExpiresAt,
+				&itemStatus, &item.Code, &item.Message, &item.Attempts, &item.ProcessedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao ler item da tarefa de operação em massa: %w", err)
+			}
+			item.Status = model.BulkUserItemStatus(itemStatus)
+			items = append(items, &item)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Save grava o estado e os contadores da tarefa e incrementa a sua versão
+func (r *BulkUserJobRepository) Save(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.Save")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", job.ID.String()),
+		attribute.String("tenant.id", job.TenantID.String()),
+		attribute.String("bulk_user_job.status", string(job.Status)),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		return saveBulkUserJob(ctx, tx, job, expectedVersion)
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	job.Version = expectedVersion + 1
+	return nil
+}
+
+// SaveResults grava o resultado dos itens, a auditoria dos usuários afetados e os contadores da tarefa
+// Apenas os itens ainda pendentes são atualizados; a condição sobre a versão da tarefa impede
+// que duas instâncias gravem o mesmo lote
+func (r *BulkUserJobRepository) SaveResults(
+	ctx context.Context,
+	job *model.BulkUserJob,
+	items []*model.BulkUserJobItem,
+	expectedVersion int64,
+) error {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.SaveResults")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", job.ID.String()),
+		attribute.String("tenant.id", job.TenantID.String()),
+		attribute.Int("bulk_user_job.items", len(items)),
+	)
+
+	itemQuery := `
+		UPDATE bulk_user_job_items
+		SET status = $4, code = NULLIF($5, ''), message = NULLIF($6, ''), attempts = $7, processed_at = $8
+		WHERE tenant_id = $1 AND job_id = $2 AND line = $3 AND status = 'pending'
+	`
+
+	// O autor é referenciado apenas quando é um usuário do tenant; fica sempre nos metadados
+	auditQuery := `
+		INSERT INTO audit_logs (
+			tenant_id, event_type, entity_type, entity_id, user_id, new_value, metadata, severity, details, created_at
+		) VALUES ($1, $2, $3, $4, (SELECT u.id FROM users u WHERE u.id = $5 AND u.tenant_id = $1), $6, $7, $8, $9, $10)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := saveBulkUserJob(ctx, tx, job, expectedVersion); err != nil {
+			return err
+		}
+
+		batch := &pgx.Batch{}
+		for _, item := range items {
+			batch.Queue(itemQuery,
+				job.TenantID, job.ID, item.Line, string(item.Status), item.Code, item.Message, item.Attempts,
+				item.ProcessedAt,
+			)
+			if item.Status != model.BulkUserItemStatusSucceeded {
+				continue
+			}
+
+			newValue, metadata, err := bulkUserAuditValues(job, item)
+			if err != nil {
+				return err
+			}
+			batch.Queue(auditQuery,
+				job.TenantID, job.Operation.AuditEventType(), bulkUserAuditEntityType, item.UserID, job.RequestedBy,
+				newValue, metadata, string(job.Operation.AuditSeverity()),
+				fmt.Sprintf("Operação em massa %s aplicada ao usuário %s pela tarefa %s (linha %d)",
+					job.Operation, item.UserID, job.ID, item.Line),
+				item.ProcessedAt,
+			)
+		}
+
+		results := tx.SendBatch(ctx, batch)
+		for i := 0; i < batch.Len(); i++ {
+			if _, err := results.Exec(); err != nil {
+				results.Close()
+				return fmt.Errorf("erro ao gravar resultados da tarefa de operação em massa: %w", err)
+			}
+		}
+		return results.Close()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	job.Version = expectedVersion + 1
+	return nil
+}
+
+// Requeue grava a tarefa retomada e devolve à fila os seus itens falhados
+func (r *BulkUserJobRepository) Requeue(ctx context.Context, job *model.BulkUserJob, expectedVersion int64) error {
+	ctx, span := tracer.Start(ctx, "BulkUserJobRepository.Requeue")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("bulk_user_job.id", job.ID.String()),
+		attribute.String("tenant.id", job.TenantID.String()),
+	)
+
+	query := `
+		UPDATE bulk_user_job_items
+		SET status = 'pending', code = NULL, message = NULL, processed_at = NULL
+		WHERE tenant_id = $1 AND job_id = $2 AND status = 'failed'
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := saveBulkUserJob(ctx, tx, job, expectedVersion); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, query, job.TenantID, job.ID); err != nil {
+			return fmt.Errorf("erro ao devolver à fila os itens falhados: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	job.Version = expectedVersion + 1
+	return nil
+}
+
+// saveBulkUserJob atualiza a tarefa na transação se estiver na versão esperada
+func saveBulkUserJob(ctx context.Context, tx pgx.Tx, job *model.BulkUserJob, expectedVersion int64) error {
+	query := `
+		UPDATE bulk_user_jobs
+		SET status = $3, processed = $4, succeeded = $5, skipped = $6, failed = $7, error = NULLIF($8, ''),
+			attempts = $9, version = version + 1, updated_at = $10, completed_at = $11
+		WHERE tenant_id = $1 AND id = $2 AND version = $12
+	`
+
+	tag, err := tx.Exec(ctx, query,
+		job.TenantID, job.ID, string(job.Status), job.Processed, job.Succeeded, job.Skipped, job.Failed, job.Error,
+		job.Attempts, job.UpdatedAt, job.CompletedAt, expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar tarefa de operação em massa: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: tarefa %s não está na versão %d", model.ErrBulkUserJobConflict, job.ID, expectedVersion)
+	}
+	return nil
+}
+
+// bulkUserAuditValues serializa o novo valor e os metadados do registo de auditoria de um item
+func bulkUserAuditValues(job *model.BulkUserJob, item *model.BulkUserJobItem) ([]byte, []byte, error) {
+	newValue := map[string]interface{}{}
+	if target, ok := job.Operation.TargetStatus(); ok {
+		newValue["status"] = target
+	}
+	if item.RoleID != nil {
+		newValue["role_id"] = item.RoleID.String()
+	}
+	if item.ExpiresAt != nil {
+		newValue["expires_at"] = item.ExpiresAt
+	}
+
+	metadata := map[string]interface{}{
+		"bulk_user_job_id": job.ID.String(),
+		"operation":        job.Operation,
+		"line":             item.Line,
+		"actor_id":         job.RequestedBy.String(),
+	}
+	if item.Reason != "" {
+		metadata["reason"] = item.Reason
+	}
+
+	newValueJSON, err := json.Marshal(newValue)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar auditoria da operação em massa: %w", err)
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar auditoria da operação em massa: %w", err)
+	}
+	return newValueJSON, metadataJSON, nil
+}
+
+// queryJobs executa uma consulta que retorna tarefas
+func (r *BulkUserJobRepository) queryJobs(ctx context.Context, query string, args ...interface{}) ([]*model.BulkUserJob, error) {
+	var jobs []*model.BulkUserJob
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar tarefas de operação em massa: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			job, err := scanBulkUserJob(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler tarefa de operação em massa: %w", err)
+			}
+			jobs = append(jobs, job)
+		}
+		return rows.Err()
+	})
+	return jobs, err
+}
+
+// scanBulkUserJob lê uma tarefa a partir de uma linha com as colunas de bulkUserJobColumns
+func scanBulkUserJob(row pgx.Row) (*model.BulkUserJob, error) {
+	var (
+		job               model.BulkUserJob
+		operation, status string
+	)
+	err := row.Scan(
+		&job.ID, &job.TenantID, &operation, &status, &job.Total, &job.Processed, &job.Succeeded, &job.Skipped,
+		&job.Failed, &job.Error, &job.RequestedBy, &job.Attempts, &job.Version, &job.CreatedAt, &job.UpdatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Operation = model.BulkUserOperation(operation)
+	job.Status = model.BulkUserJobStatus(status)
+	return &job, nil
+}
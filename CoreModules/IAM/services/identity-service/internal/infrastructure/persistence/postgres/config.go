@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
 )
 
 var tracer = otel.Tracer("innovabiz.iam.infrastructure.persistence.postgres")
@@ -204,26 +208,132 @@ func (db *DB) Close() {
 	}
 }
 
-// Pool retorna o pool de conexões para uso direto quando necessário
-func (db *DB) Pool() *pgxpool.Pool {
-	return db.pool
+// querier é a interface comum ao pool e às transações usada pelas consultas guardadas
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// errRow é a linha devolvida por QueryRow quando a consulta é recusada
+type errRow struct {
+	err error
+}
+
+// Scan retorna o erro que recusou a consulta
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// querier exige o tenant no contexto e devolve a transação em curso, ou o pool fora de transação
+func (db *DB) querier(ctx context.Context) (querier, error) {
+	tenantID, err := requireTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if outer, ok := ctx.Value(txContextKey{}).(*activeTx); ok {
+		if outer.tenantID != tenantID {
+			return nil, fmt.Errorf("transação em curso pertence a outro tenant")
+		}
+		return outer.tx, nil
+	}
+	return db.pool, nil
+}
+
+// Exec executa um comando exigindo o tenant no contexto, na transação em curso quando existir
+func (db *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.Exec(ctx, sql, args...)
+}
+
+// Query executa uma consulta exigindo o tenant no contexto, na transação em curso quando existir
+func (db *DB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.Query(ctx, sql, args...)
+}
+
+// QueryRow executa uma consulta de uma linha exigindo o tenant no contexto; a recusa é
+// devolvida pelo Scan da linha
+func (db *DB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	q, err := db.querier(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return q.QueryRow(ctx, sql, args...)
+}
+
+// txContextKey é a chave da transação em curso no contexto
+type txContextKey struct{}
+
+// activeTx é a transação em curso e o tenant exposto às políticas de row-level security
+type activeTx struct {
+	tx       pgx.Tx
+	tenantID uuid.UUID
+}
+
+// WithinTransaction executa fn numa transação partilhada pelos repositórios chamados com o
+// contexto recebido, implementando repository.TransactionManager
+func (db *DB) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.InTransaction(ctx, func(ctx context.Context, _ pgx.Tx) error {
+		return fn(ctx)
+	})
 }
 
 // InTransaction executa operações dentro de uma transação com rastreamento OpenTelemetry
+// As transações exigem um tenant no contexto, exceto nas tarefas do sistema (requestctx.WithSystemScope)
+// Dentro de uma transação em curso as operações correm num savepoint: a falha reverte apenas
+// as suas alterações e a transação externa decide o commit
 func (db *DB) InTransaction(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
 	ctx, span := tracer.Start(ctx, "PostgreSQL.InTransaction")
 	defer span.End()
 
-	// Iniciar a transação
-	tx, err := db.pool.Begin(ctx)
+	tenantID, err := requireTenant(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+	if tenantID != uuid.Nil {
+		span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+	} else if component, ok := requestctx.SystemScope(ctx); ok {
+		span.SetAttributes(attribute.String("system.scope", component))
+	}
+
+	// Iniciar a transação, ou um savepoint da transação em curso no mesmo tenant
+	var tx pgx.Tx
+	outer, nested := ctx.Value(txContextKey{}).(*activeTx)
+	if nested {
+		if outer.tenantID != tenantID {
+			err = fmt.Errorf("transação em curso pertence a outro tenant")
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+			return err
+		}
+		span.SetAttributes(attribute.Bool("db.savepoint", true))
+		tx, err = outer.tx.Begin(ctx)
+	} else {
+		tx, err = db.pool.Begin(ctx)
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		span.RecordError(err)
 		return fmt.Errorf("erro ao iniciar transação: %w", err)
 	}
 
-	// Executar a função dentro da transação
-	err = fn(ctx, tx)
+	// Expor o tenant e o usuário do pedido às políticas de row-level security,
+	// apenas durante a transação; depois executar a função dentro da transação
+	if !nested {
+		err = setTransactionContext(ctx, tx, tenantID)
+	}
+	if err == nil {
+		err = fn(context.WithValue(ctx, txContextKey{}, &activeTx{tx: tx, tenantID: tenantID}), tx)
+	}
 	
 	// Determinar se é necessário commit ou rollback
 	if err != nil {
@@ -248,4 +358,30 @@ func (db *DB) InTransaction(ctx context.Context, fn func(ctx context.Context, tx
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// requireTenant recusa as consultas sem tenant no contexto; no âmbito do sistema retorna uuid.Nil
+func requireTenant(ctx context.Context) (uuid.UUID, error) {
+	tenantID, err := requestctx.RequireTenant(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Consulta ao PostgreSQL recusada sem tenant no contexto")
+		return uuid.Nil, fmt.Errorf("consulta ao PostgreSQL recusada: %w", err)
+	}
+	return tenantID, nil
+}
+
+// setTransactionContext define app.current_tenant e app.current_user_id na transação
+func setTransactionContext(ctx context.Context, tx pgx.Tx, tenantID uuid.UUID) error {
+	if tenantID == uuid.Nil {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, "SELECT set_config('app.current_tenant', $1, true)", tenantID.String()); err != nil {
+		return fmt.Errorf("erro ao definir o tenant da transação: %w", err)
+	}
+	if actor, ok := requestctx.ActorFromContext(ctx); ok && actor.UserID != uuid.Nil {
+		if _, err := tx.Exec(ctx, "SELECT set_config('app.current_user_id', $1, true)", actor.UserID.String()); err != nil {
+			return fmt.Errorf("erro ao definir o usuário da transação: %w", err)
+		}
+	}
+	return nil
+}
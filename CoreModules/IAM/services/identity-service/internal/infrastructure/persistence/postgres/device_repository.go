@@ -0,0 +1,391 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório dos dispositivos
+const deviceColumns = `
+	id, tenant_id, user_id, name, fingerprint, COALESCE(reuse_key, ''), identified, COALESCE(user_agent, ''),
+	COALESCE(last_ip_address, ''), COALESCE(last_country, ''), COALESCE(last_method, ''), status, login_count,
+	first_seen_at, last_seen_at, trusted_at, trust_expires_at, trusted_by, revoked_at, revoked_by, reuse_detected_at
+`
+
+// DeviceRepository implementa a interface repository.DeviceRepository usando PostgreSQL
+type DeviceRepository struct {
+	db *DB
+}
+
+// NewDeviceRepository cria uma nova instância do DeviceRepository
+func NewDeviceRepository(db *DB) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+// GetPolicy recupera a política de dispositivos do tenant, ou nil se não existir
+func (r *DeviceRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.DevicePolicy, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.GetPolicy")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT tenant_id, trust_enabled, trust_days, max_trusted_devices, reuse_threshold,
+			COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM device_policies
+		WHERE tenant_id = $1
+	`
+
+	var policy *model.DevicePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var found model.DevicePolicy
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&found.TenantID, &found.TrustEnabled, &found.TrustDays, &found.MaxTrustedDevices,
+			&found.ReuseThreshold, &found.UpdatedBy, &found.UpdatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar política de dispositivos: %w", err)
+		}
+		policy = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// SavePolicy grava a política de dispositivos do tenant, substituindo a anterior
+func (r *DeviceRepository) SavePolicy(ctx context.Context, policy *model.DevicePolicy) error {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.SavePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.Bool("device.trust_enabled", policy.TrustEnabled),
+	)
+
+	query := `
+		INSERT INTO device_policies (
+			tenant_id, trust_enabled, trust_days, max_trusted_devices, reuse_threshold, updated_by, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			trust_enabled = EXCLUDED.trust_enabled,
+			trust_days = EXCLUDED.trust_days,
+			max_trusted_devices = EXCLUDED.max_trusted_devices,
+			reuse_threshold = EXCLUDED.reuse_threshold,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			policy.TenantID, policy.TrustEnabled, policy.TrustDays, policy.MaxTrustedDevices,
+			policy.ReuseThreshold, policy.UpdatedBy, policy.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar política de dispositivos: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordSighting regista o login a partir do dispositivo
+// O índice único parcial sobre os dispositivos ativos faz do registo uma única instrução: um
+// dispositivo revogado não entra em conflito e o login regista um dispositivo novo
+func (r *DeviceRepository) RecordSighting(ctx context.Context, device *model.Device) (bool, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.RecordSighting")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", device.TenantID.String()),
+		attribute.String("user.id", device.UserID.String()),
+	)
+
+	query := `
+		INSERT INTO user_devices (
+			id, tenant_id, user_id, name, fingerprint, reuse_key, identified, user_agent, last_ip_address,
+			last_country, last_method, status, login_count, first_seen_at, last_seen_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''),
+			NULLIF($11, ''), $12, $13, $14, $15)
+		ON CONFLICT (tenant_id, user_id, fingerprint) WHERE status = 'active' DO UPDATE SET
+			user_agent = COALESCE(EXCLUDED.user_agent, user_devices.user_agent),
+			last_ip_address = COALESCE(EXCLUDED.last_ip_address, user_devices.last_ip_address),
+			last_country = COALESCE(EXCLUDED.last_country, user_devices.last_country),
+			last_method = COALESCE(EXCLUDED.last_method, user_devices.last_method),
+			login_count = user_devices.login_count + 1,
+			last_seen_at = GREATEST(user_devices.last_seen_at, EXCLUDED.last_seen_at)
+		RETURNING ` + deviceColumns + `, (xmax = 0)
+	`
+
+	var registered bool
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanDevice(tx.QueryRow(ctx, query,
+			device.ID, device.TenantID, device.UserID, device.Name, device.Fingerprint, device.ReuseKey,
+			device.Identified, device.UserAgent, device.LastIPAddress, device.LastCountry, device.LastMethod,
+			string(device.Status), device.LoginCount, device.FirstSeenAt, device.LastSeenAt,
+		), &registered)
+		if err != nil {
+			return fmt.Errorf("erro ao registar dispositivo: %w", err)
+		}
+		*device = *found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return false, err
+	}
+
+	return registered, nil
+}
+
+// FindByFingerprint recupera o dispositivo ativo do usuário com a impressão indicada
+// Sem dispositivo ativo, retorna o revogado mais recente
+func (r *DeviceRepository) FindByFingerprint(ctx context.Context, tenantID, userID uuid.UUID, fingerprint string) (*model.Device, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.FindByFingerprint")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + deviceColumns + `
+		FROM user_devices
+		WHERE tenant_id = $1 AND user_id = $2 AND fingerprint = $3
+		ORDER BY status = 'active' DESC, last_seen_at DESC
+		LIMIT 1
+	`
+
+	var device *model.Device
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanDevice(tx.QueryRow(ctx, query, tenantID, userID, fingerprint))
+		if err == pgx.ErrNoRows {
+			return model.ErrDeviceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		device = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// ListAccountsByReuseKey recupera os usuários com um dispositivo ativo com a chave de partilha indicada
+func (r *DeviceRepository) ListAccountsByReuseKey(ctx context.Context, tenantID uuid.UUID, reuseKey string, since time.Time) ([]uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.ListAccountsByReuseKey")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT DISTINCT user_id
+		FROM user_devices
+		WHERE tenant_id = $1 AND reuse_key = $2 AND status = 'active' AND last_seen_at >= $3
+		ORDER BY user_id
+	`
+
+	var accounts []uuid.UUID
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, reuseKey, since)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar contas do dispositivo: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var userID uuid.UUID
+			if err := rows.Scan(&userID); err != nil {
+				return fmt.Errorf("erro ao ler conta do dispositivo: %w", err)
+			}
+			accounts = append(accounts, userID)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// List recupera os dispositivos do usuário, do usado mais recentemente para o mais antigo
+func (r *DeviceRepository) List(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.Device, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.List")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + deviceColumns + `
+		FROM user_devices
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY last_seen_at DESC, id
+	`
+
+	var devices []*model.Device
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar dispositivos: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			device, err := scanDevice(rows)
+			if err != nil {
+				return err
+			}
+			devices = append(devices, device)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// Get recupera um dispositivo do usuário
+func (r *DeviceRepository) Get(ctx context.Context, tenantID, userID, deviceID uuid.UUID) (*model.Device, error) {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("device.id", deviceID.String()),
+	)
+
+	query := `SELECT ` + deviceColumns + `
+		FROM user_devices
+		WHERE tenant_id = $1 AND user_id = $2 AND id = $3
+	`
+
+	var device *model.Device
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanDevice(tx.QueryRow(ctx, query, tenantID, userID, deviceID))
+		if err == pgx.ErrNoRows {
+			return model.ErrDeviceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		device = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// Update grava o nome, a confiança, o estado e a sinalização de partilha do dispositivo
+func (r *DeviceRepository) Update(ctx context.Context, device *model.Device) error {
+	ctx, span := tracer.Start(ctx, "DeviceRepository.Update")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", device.TenantID.String()),
+		attribute.String("device.id", device.ID.String()),
+		attribute.String("device.status", string(device.Status)),
+	)
+
+	query := `
+		UPDATE user_devices SET
+			name = $4, status = $5, trusted_at = $6, trust_expires_at = $7, trusted_by = $8,
+			revoked_at = $9, revoked_by = $10, reuse_detected_at = $11
+		WHERE tenant_id = $1 AND user_id = $2 AND id = $3
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query,
+			device.TenantID, device.UserID, device.ID, device.Name, string(device.Status), device.TrustedAt,
+			device.TrustExpiresAt, device.TrustedBy, device.RevokedAt, device.RevokedBy, device.ReuseDetectedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar dispositivo: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrDeviceNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanDevice lê um dispositivo, seguido das colunas adicionais da consulta
+func scanDevice(row pgx.Row, extra ...interface{}) (*model.Device, error) {
+	var (
+		device model.Device
+		status string
+	)
+	dest := []interface{}{
+		&device.ID, &device.TenantID, &device.UserID, &device.Name, &device.Fingerprint, &device.ReuseKey,
+		&device.Identified, &device.UserAgent, &device.LastIPAddress, &device.LastCountry, &device.LastMethod,
+		&status, &device.LoginCount, &device.FirstSeenAt, &device.LastSeenAt, &device.TrustedAt,
+		&device.TrustExpiresAt, &device.TrustedBy, &device.RevokedAt, &device.RevokedBy, &device.ReuseDetectedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler dispositivo: %w", err)
+	}
+	device.Status = model.DeviceStatus(status)
+	return &device, nil
+}
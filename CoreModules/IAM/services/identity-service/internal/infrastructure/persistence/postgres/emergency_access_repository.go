@@ -0,0 +1,522 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório das contas de emergência
+const emergencyAccountColumns = `
+	id, tenant_id, user_id, name, COALESCE(description, ''), created_by, created_at
+`
+
+const emergencyActivationColumns = `
+	id, tenant_id, account_id, user_id, incident_ref, justification, duration_seconds, status,
+	requested_by, requested_at, decided_by, decided_at, COALESCE(decision_note, ''), expires_at,
+	ended_at, ended_by, COALESCE(end_reason, ''), reviewed_by, reviewed_at,
+	COALESCE(review_outcome, ''), COALESCE(review_notes, '')
+`
+
+const emergencyActionColumns = `
+	id, tenant_id, activation_id, user_id, sequence, method, path, COALESCE(query, ''),
+	ip_address, COALESCE(user_agent, ''), COALESCE(request_id, ''), occurred_at, prev_hash, hash
+`
+
+// EmergencyAccessRepository implementa a interface repository.EmergencyAccessRepository usando PostgreSQL
+type EmergencyAccessRepository struct {
+	db *DB
+}
+
+// NewEmergencyAccessRepository cria uma nova instância do EmergencyAccessRepository
+func NewEmergencyAccessRepository(db *DB) *EmergencyAccessRepository {
+	return &EmergencyAccessRepository{db: db}
+}
+
+// CreateAccount grava uma nova conta de emergência
+func (r *EmergencyAccessRepository) CreateAccount(ctx context.Context, account *model.EmergencyAccount) error {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.CreateAccount")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", account.TenantID.String()),
+		attribute.String("emergency_account.id", account.ID.String()),
+		attribute.String("user.id", account.UserID.String()),
+	)
+
+	query := `
+		INSERT INTO emergency_accounts (id, tenant_id, user_id, name, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			account.ID, account.TenantID, account.UserID, account.Name, account.Description,
+			account.CreatedBy, account.CreatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrEmergencyAccountConflict
+			}
+			return fmt.Errorf("erro ao inserir conta de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetAccount recupera uma conta de emergência do tenant
+func (r *EmergencyAccessRepository) GetAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.EmergencyAccount, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.GetAccount")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("emergency_account.id", accountID.String()),
+	)
+
+	query := `SELECT ` + emergencyAccountColumns + `
+		FROM emergency_accounts
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var account model.EmergencyAccount
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, tenantID, accountID).Scan(
+			&account.ID, &account.TenantID, &account.UserID, &account.Name, &account.Description,
+			&account.CreatedBy, &account.CreatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return model.ErrEmergencyAccountNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler conta de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// ListAccounts recupera as contas de emergência do tenant, ordenadas pelo nome
+func (r *EmergencyAccessRepository) ListAccounts(ctx context.Context, tenantID uuid.UUID) ([]*model.EmergencyAccount, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.ListAccounts")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + emergencyAccountColumns + `
+		FROM emergency_accounts
+		WHERE tenant_id = $1
+		ORDER BY name
+	`
+
+	var accounts []*model.EmergencyAccount
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar contas de emergência: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var account model.EmergencyAccount
+			if err := rows.Scan(
+				&account.ID, &account.TenantID, &account.UserID, &account.Name, &account.Description,
+				&account.CreatedBy, &account.CreatedAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler conta de emergência: %w", err)
+			}
+			accounts = append(accounts, &account)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// CreateActivation grava um novo pedido de ativação
+func (r *EmergencyAccessRepository) CreateActivation(ctx context.Context, activation *model.EmergencyActivation) error {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.CreateActivation")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", activation.TenantID.String()),
+		attribute.String("emergency_activation.id", activation.ID.String()),
+		attribute.String("emergency_account.id", activation.AccountID.String()),
+	)
+
+	query := `
+		INSERT INTO emergency_activations (
+			id, tenant_id, account_id, user_id, incident_ref, justification, duration_seconds, status,
+			requested_by, requested_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			activation.ID, activation.TenantID, activation.AccountID, activation.UserID, activation.IncidentRef,
+			activation.Justification, activation.DurationSeconds, string(activation.Status),
+			activation.RequestedBy, activation.RequestedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrEmergencyActivationOpen
+			}
+			return fmt.Errorf("erro ao inserir ativação da conta de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetActivation recupera uma ativação do tenant
+func (r *EmergencyAccessRepository) GetActivation(ctx context.Context, tenantID, activationID uuid.UUID) (*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.GetActivation")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("emergency_activation.id", activationID.String()),
+	)
+
+	query := `SELECT ` + emergencyActivationColumns + `
+		FROM emergency_activations
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var activation *model.EmergencyActivation
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanEmergencyActivation(tx.QueryRow(ctx, query, tenantID, activationID))
+		if err == pgx.ErrNoRows {
+			return model.ErrEmergencyActivationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		activation = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return activation, nil
+}
+
+// ListActivations recupera as ativações do tenant, mais recentes primeiro
+func (r *EmergencyAccessRepository) ListActivations(ctx context.Context, tenantID uuid.UUID, status model.EmergencyActivationStatus) ([]*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.ListActivations")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("emergency_activation.status", string(status)),
+	)
+
+	query := `SELECT ` + emergencyActivationColumns + `
+		FROM emergency_activations
+		WHERE tenant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY requested_at DESC
+	`
+
+	activations, err := r.queryActivations(ctx, query, tenantID, string(status))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return activations, nil
+}
+
+// UpdateActivation grava a transição de estado de uma ativação que estava no estado from
+func (r *EmergencyAccessRepository) UpdateActivation(ctx context.Context, activation *model.EmergencyActivation, from model.EmergencyActivationStatus) error {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.UpdateActivation")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", activation.TenantID.String()),
+		attribute.String("emergency_activation.id", activation.ID.String()),
+		attribute.String("emergency_activation.from", string(from)),
+		attribute.String("emergency_activation.status", string(activation.Status)),
+	)
+
+	query := `
+		UPDATE emergency_activations SET
+			status = $4, decided_by = $5, decided_at = $6, decision_note = NULLIF($7, ''), expires_at = $8,
+			ended_at = $9, ended_by = $10, end_reason = NULLIF($11, ''), reviewed_by = $12, reviewed_at = $13,
+			review_outcome = NULLIF($14, ''), review_notes = NULLIF($15, '')
+		WHERE tenant_id = $1 AND id = $2 AND status = $3
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			activation.TenantID, activation.ID, string(from), string(activation.Status),
+			activation.DecidedBy, activation.DecidedAt, activation.DecisionNote, activation.ExpiresAt,
+			activation.EndedAt, activation.EndedBy, activation.EndReason, activation.ReviewedBy,
+			activation.ReviewedAt, string(activation.ReviewOutcome), activation.ReviewNotes,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar ativação da conta de emergência: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrEmergencyActivationState
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListDueActivations recupera, de todos os tenants, as ativações expiradas e os pedidos sem aprovação no prazo
+func (r *EmergencyAccessRepository) ListDueActivations(ctx context.Context, now, pendingBefore time.Time, limit int) ([]*model.EmergencyActivation, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.ListDueActivations")
+	defer span.End()
+
+	query := `SELECT ` + emergencyActivationColumns + `
+		FROM emergency_activations
+		WHERE (status = 'active' AND expires_at <= $1)
+			OR (status = 'pending_approval' AND requested_at <= $2)
+		ORDER BY requested_at
+		LIMIT $3
+	`
+
+	activations, err := r.queryActivations(ctx, query, now, pendingBefore, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("emergency_activation.count", len(activations)))
+	return activations, nil
+}
+
+// AppendAction acrescenta uma ação ao registo da ativação
+// A linha da ativação é bloqueada durante a transação para que os registos concorrentes sejam
+// encadeados um a seguir ao outro
+func (r *EmergencyAccessRepository) AppendAction(ctx context.Context, action *model.EmergencyAction) error {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.AppendAction")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", action.TenantID.String()),
+		attribute.String("emergency_activation.id", action.ActivationID.String()),
+	)
+
+	lockQuery := `
+		SELECT status, expires_at
+		FROM emergency_activations
+		WHERE tenant_id = $1 AND id = $2
+		FOR UPDATE
+	`
+
+	lastQuery := `SELECT ` + emergencyActionColumns + `
+		FROM emergency_actions
+		WHERE activation_id = $1
+		ORDER BY sequence DESC
+		LIMIT 1
+	`
+
+	insertQuery := `
+		INSERT INTO emergency_actions (
+			id, tenant_id, activation_id, user_id, sequence, method, path, query, ip_address,
+			user_agent, request_id, occurred_at, prev_hash, hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''), $12, $13, $14)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var (
+			status    string
+			expiresAt *time.Time
+		)
+		err := tx.QueryRow(ctx, lockQuery, action.TenantID, action.ActivationID).Scan(&status, &expiresAt)
+		if err == pgx.ErrNoRows {
+			return model.ErrEmergencyActivationState
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao bloquear ativação da conta de emergência: %w", err)
+		}
+		activation := model.EmergencyActivation{Status: model.EmergencyActivationStatus(status), ExpiresAt: expiresAt}
+		if !activation.IsActive(action.OccurredAt) {
+			return model.ErrEmergencyActivationState
+		}
+
+		previous, err := scanEmergencyAction(tx.QueryRow(ctx, lastQuery, action.ActivationID))
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		action.Chain(previous)
+
+		_, err = tx.Exec(ctx, insertQuery,
+			action.ID, action.TenantID, action.ActivationID, action.UserID, action.Sequence, action.Method,
+			action.Path, action.Query, action.IPAddress, action.UserAgent, action.RequestID, action.OccurredAt,
+			action.PrevHash, action.Hash,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir ação da conta de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListActions recupera as ações de uma ativação com sequência superior a afterSequence
+func (r *EmergencyAccessRepository) ListActions(ctx context.Context, tenantID, activationID uuid.UUID, afterSequence int64, limit int) ([]*model.EmergencyAction, error) {
+	ctx, span := tracer.Start(ctx, "EmergencyAccessRepository.ListActions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("emergency_activation.id", activationID.String()),
+		attribute.Int64("emergency_action.after", afterSequence),
+	)
+
+	query := `SELECT ` + emergencyActionColumns + `
+		FROM emergency_actions
+		WHERE tenant_id = $1 AND activation_id = $2 AND sequence > $3
+		ORDER BY sequence
+		LIMIT $4
+	`
+
+	var actions []*model.EmergencyAction
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, activationID, afterSequence, limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar ações da conta de emergência: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			action, err := scanEmergencyAction(rows)
+			if err != nil {
+				return err
+			}
+			actions = append(actions, action)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return actions, nil
+}
+
+// queryActivations executa uma consulta de ativações
+func (r *EmergencyAccessRepository) queryActivations(ctx context.Context, query string, args ...interface{}) ([]*model.EmergencyActivation, error) {
+	var activations []*model.EmergencyActivation
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar ativações das contas de emergência: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			activation, err := scanEmergencyActivation(rows)
+			if err != nil {
+				return err
+			}
+			activations = append(activations, activation)
+		}
+		return rows.Err()
+	})
+	return activations, err
+}
+
+// scanEmergencyActivation lê uma ativação de uma linha
+func scanEmergencyActivation(row pgx.Row) (*model.EmergencyActivation, error) {
+	var (
+		activation      model.EmergencyActivation
+		status, outcome string
+	)
+	err := row.Scan(
+		&activation.ID, &activation.TenantID, &activation.AccountID, &activation.UserID, &activation.IncidentRef,
+		&activation.Justification, &activation.DurationSeconds, &status, &activation.RequestedBy,
+		&activation.RequestedAt, &activation.DecidedBy, &activation.DecidedAt, &activation.DecisionNote,
+		&activation.ExpiresAt, &activation.EndedAt, &activation.EndedBy, &activation.EndReason,
+		&activation.ReviewedBy, &activation.ReviewedAt, &outcome, &activation.ReviewNotes,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler ativação da conta de emergência: %w", err)
+	}
+	activation.Status = model.EmergencyActivationStatus(status)
+	activation.ReviewOutcome = model.EmergencyReviewOutcome(outcome)
+	return &activation, nil
+}
+
+// scanEmergencyAction lê uma ação de uma linha
+func scanEmergencyAction(row pgx.Row) (*model.EmergencyAction, error) {
+	var action model.EmergencyAction
+	err := row.Scan(
+		&action.ID, &action.TenantID, &action.ActivationID, &action.UserID, &action.Sequence, &action.Method,
+		&action.Path, &action.Query, &action.IPAddress, &action.UserAgent, &action.RequestID,
+		&action.OccurredAt, &action.PrevHash, &action.Hash,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler ação da conta de emergência: %w", err)
+	}
+	return &action, nil
+}
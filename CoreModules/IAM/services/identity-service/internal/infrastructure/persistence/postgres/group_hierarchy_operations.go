@@ -59,7 +59,7 @@ func (r *GroupRepository) GetParentGroup(ctx context.Context, groupID, tenantID
 
 	tx := persistence.GetTxFromContext(ctx)
 
-	var row *sqlx.Row
+	var row rowScanner
 	if tx != nil {
 		row = tx.QueryRowxContext(ctx, query, groupID, tenantID)
 	} else {
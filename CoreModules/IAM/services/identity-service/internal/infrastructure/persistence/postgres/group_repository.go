@@ -36,7 +36,7 @@ import (
 
 // GroupRepository implementa a interface group.Repository para PostgreSQL
 type GroupRepository struct {
-	db      *sqlx.DB
+	db      tenantGuardedDB
 	logger  logging.Logger
 	metrics metrics.MetricsClient
 	tracer  trace.Tracer
@@ -50,13 +50,63 @@ func NewGroupRepository(
 	tracer trace.Tracer,
 ) *GroupRepository {
 	return &GroupRepository{
-		db:      db,
+		db:      tenantGuardedDB{db: db},
 		logger:  logger,
 		metrics: metrics,
 		tracer:  tracer,
 	}
 }
 
+// rowScanner é a linha de uma consulta sqlx, ou a recusa da consulta sem tenant
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// tenantGuardedDB envolve a conexão sqlx e recusa as consultas sem tenant no contexto
+type tenantGuardedDB struct {
+	db *sqlx.DB
+}
+
+// ExecContext executa um comando exigindo o tenant no contexto
+func (g tenantGuardedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if _, err := requireTenant(ctx); err != nil {
+		return nil, err
+	}
+	return g.db.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executa uma consulta exigindo o tenant no contexto
+func (g tenantGuardedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if _, err := requireTenant(ctx); err != nil {
+		return nil, err
+	}
+	return g.db.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executa uma consulta de uma linha exigindo o tenant no contexto
+func (g tenantGuardedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) rowScanner {
+	if _, err := requireTenant(ctx); err != nil {
+		return errRow{err: err}
+	}
+	return g.db.QueryRowContext(ctx, query, args...)
+}
+
+// QueryRowxContext executa uma consulta sqlx de uma linha exigindo o tenant no contexto
+func (g tenantGuardedDB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) rowScanner {
+	if _, err := requireTenant(ctx); err != nil {
+		return errRow{err: err}
+	}
+	return g.db.QueryRowxContext(ctx, query, args...)
+}
+
+// BeginTxx inicia uma transação exigindo o tenant no contexto
+func (g tenantGuardedDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	if _, err := requireTenant(ctx); err != nil {
+		return nil, err
+	}
+	return g.db.BeginTxx(ctx, opts)
+}
+
 // GetByID busca um grupo pelo ID
 func (r *GroupRepository) GetByID(ctx context.Context, id uuid.UUID, tenantID uuid.UUID) (*group.Group, error) {
 	ctx, span := r.tracer.Start(ctx, "GroupRepository.GetByID")
@@ -84,7 +134,7 @@ func (r *GroupRepository) GetByID(ctx context.Context, id uuid.UUID, tenantID uu
 	// Extrair transação do contexto se existir
 	tx := persistence.GetTxFromContext(ctx)
 
-	var row *sqlx.Row
+	var row rowScanner
 	if tx != nil {
 		row = tx.QueryRowxContext(ctx, query, id, tenantID)
 	} else {
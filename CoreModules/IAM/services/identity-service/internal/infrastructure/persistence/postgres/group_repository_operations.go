@@ -60,7 +60,7 @@ func (r *GroupRepository) GetByCode(ctx context.Context, code string, tenantID u
 	// Extrair transação do contexto se existir
 	tx := persistence.GetTxFromContext(ctx)
 
-	var row *sqlx.Row
+	var row rowScanner
 	if tx != nil {
 		row = tx.QueryRowxContext(ctx, query, code, tenantID)
 	} else {
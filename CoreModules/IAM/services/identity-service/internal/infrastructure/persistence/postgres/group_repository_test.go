@@ -34,6 +34,8 @@ import (
 	"github.com/innovabiz/iam/internal/infrastructure/logging"
 	"github.com/innovabiz/iam/internal/infrastructure/metrics"
 	"github.com/innovabiz/iam/internal/infrastructure/tracing"
+
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
 )
 
 // setupMockDB configura um banco de dados mock para testes
@@ -55,9 +57,9 @@ func TestGetByID(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	createdBy := uuid.New()
 	updatedBy := uuid.New()
 	parentGroupID := uuid.New()
@@ -126,9 +128,9 @@ func TestGetByIDNotFound(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 
 	// Configurar expectativas mock para não encontrar o grupo
 	mock.ExpectQuery("SELECT (.+) FROM iam_groups").
@@ -151,9 +153,9 @@ func TestGetByCode(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	createdBy := uuid.New()
 	parentGroupID := uuid.New()
 	createdAt := time.Now().UTC()
@@ -196,9 +198,9 @@ func TestCreate(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	createdBy := uuid.New()
 
 	// Criar grupo de teste
@@ -243,10 +245,10 @@ func TestCreateWithParent(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	parentID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	createdBy := uuid.New()
 
 	// Criar grupo de teste
@@ -314,9 +316,9 @@ func TestUpdate(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	updatedBy := uuid.New()
 	createdBy := uuid.New()
 	createdAt := time.Now().UTC().Add(-24 * time.Hour) // criado ontem
@@ -395,10 +397,10 @@ func TestAddUserToGroup(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	userID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	addedBy := uuid.New()
 
 	// Mock para verificação se o grupo existe
@@ -444,10 +446,10 @@ func TestRemoveUserFromGroup(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	userID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	removedBy := uuid.New()
 
 	// Mock para verificação se o grupo existe
@@ -492,8 +494,8 @@ func TestList(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	groupID1 := uuid.New()
 	groupID2 := uuid.New()
 	createdBy := uuid.New()
@@ -553,10 +555,10 @@ func TestGetParentGroup(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
 
-	ctx := context.Background()
 	groupID := uuid.New()
 	parentID := uuid.New()
 	tenantID := uuid.New()
+	ctx := requestctx.WithTenant(context.Background(), tenantID)
 	createdBy := uuid.New()
 
 	// Definir colunas esperadas
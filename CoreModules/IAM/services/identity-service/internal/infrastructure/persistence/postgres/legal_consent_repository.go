@@ -0,0 +1,380 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório dos documentos legais
+const legalDocumentColumns = `
+	id, tenant_id, type, version, title, url, content_hash, required, effective_at, published_by, created_at
+`
+
+const legalAcceptanceColumns = `
+	id, tenant_id, user_id, document_id, document_type, version, document_hash, accepted_at,
+	COALESCE(ip_address, ''), COALESCE(user_agent, '')
+`
+
+// Versão em vigor de cada tipo de documento do tenant ($1) no instante $2
+const currentLegalDocumentsQuery = `
+	SELECT DISTINCT ON (type) ` + legalDocumentColumns + `
+	FROM legal_documents
+	WHERE tenant_id = $1 AND effective_at <= $2
+	ORDER BY type, effective_at DESC, created_at DESC
+`
+
+// Usuários que podem autenticar-se, contados nos relatórios de aceitação
+const legalConsentActiveUserCondition = `u.status IN ('active', 'pending') AND u.deleted_at IS NULL`
+
+// LegalConsentRepository implementa a interface repository.LegalConsentRepository usando PostgreSQL
+type LegalConsentRepository struct {
+	db *DB
+}
+
+// NewLegalConsentRepository cria uma nova instância do LegalConsentRepository
+func NewLegalConsentRepository(db *DB) *LegalConsentRepository {
+	return &LegalConsentRepository{db: db}
+}
+
+// CreateDocument grava uma nova versão de um documento legal
+func (r *LegalConsentRepository) CreateDocument(ctx context.Context, document *model.LegalDocument) error {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.CreateDocument")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", document.TenantID.String()),
+		attribute.String("legal_document.type", string(document.Type)),
+		attribute.String("legal_document.version", document.Version),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO legal_documents (
+				id, tenant_id, type, version, title, url, content_hash, required, effective_at, published_by, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`,
+			document.ID, document.TenantID, string(document.Type), document.Version, document.Title, document.URL,
+			document.ContentHash, document.Required, document.EffectiveAt, document.PublishedBy, document.CreatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrLegalDocumentVersionExists
+			}
+			return fmt.Errorf("erro ao inserir documento legal: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDocument recupera uma versão de um documento legal do tenant
+func (r *LegalConsentRepository) GetDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.GetDocument")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("legal_document.id", documentID.String()),
+	)
+
+	query := `SELECT ` + legalDocumentColumns + `
+		FROM legal_documents
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var document *model.LegalDocument
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		document, err = scanLegalDocument(tx.QueryRow(ctx, query, tenantID, documentID))
+		if err == pgx.ErrNoRows {
+			return model.ErrLegalDocumentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar documento legal: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// ListDocuments recupera as versões publicadas pelo tenant, mais recentes primeiro
+func (r *LegalConsentRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID) ([]*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.ListDocuments")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + legalDocumentColumns + `
+		FROM legal_documents
+		WHERE tenant_id = $1
+		ORDER BY effective_at DESC, created_at DESC
+	`
+
+	documents, err := r.queryDocuments(ctx, query, tenantID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// ListCurrentDocuments recupera a versão em vigor de cada tipo de documento do tenant
+func (r *LegalConsentRepository) ListCurrentDocuments(ctx context.Context, tenantID uuid.UUID, now time.Time) ([]*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.ListCurrentDocuments")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	documents, err := r.queryDocuments(ctx, currentLegalDocumentsQuery, tenantID, now)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// ListUnacceptedDocuments recupera as versões em vigor que o usuário ainda não aceitou
+func (r *LegalConsentRepository) ListUnacceptedDocuments(ctx context.Context, tenantID, userID uuid.UUID, now time.Time) ([]*model.LegalDocument, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.ListUnacceptedDocuments")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `WITH current_documents AS (` + currentLegalDocumentsQuery + `)
+		SELECT ` + legalDocumentColumns + `
+		FROM current_documents d
+		WHERE NOT EXISTS (
+			SELECT 1 FROM legal_acceptances a
+			WHERE a.tenant_id = $1 AND a.user_id = $3 AND a.document_id = d.id
+		)
+		ORDER BY type
+	`
+
+	documents, err := r.queryDocuments(ctx, query, tenantID, now, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// SaveAcceptance grava a aceitação; quando o usuário já aceitou a versão, retorna a aceitação existente
+func (r *LegalConsentRepository) SaveAcceptance(ctx context.Context, acceptance *model.LegalAcceptance) (*model.LegalAcceptance, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.SaveAcceptance")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", acceptance.TenantID.String()),
+		attribute.String("user.id", acceptance.UserID.String()),
+		attribute.String("legal_document.id", acceptance.DocumentID.String()),
+	)
+
+	saved := acceptance
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO legal_acceptances (
+				id, tenant_id, user_id, document_id, document_type, version, document_hash, accepted_at,
+				ip_address, user_agent
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
+			ON CONFLICT (user_id, document_id) DO NOTHING
+		`,
+			acceptance.ID, acceptance.TenantID, acceptance.UserID, acceptance.DocumentID,
+			string(acceptance.DocumentType), acceptance.Version, acceptance.DocumentHash, acceptance.AcceptedAt,
+			acceptance.IPAddress, acceptance.UserAgent,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir aceitação do documento legal: %w", err)
+		}
+		if tag.RowsAffected() > 0 {
+			return nil
+		}
+
+		// A versão já tinha sido aceite: a prova é a aceitação original
+		saved, err = scanLegalAcceptance(tx.QueryRow(ctx, `SELECT `+legalAcceptanceColumns+`
+			FROM legal_acceptances
+			WHERE tenant_id = $1 AND user_id = $2 AND document_id = $3
+		`, acceptance.TenantID, acceptance.UserID, acceptance.DocumentID))
+		if err != nil {
+			return fmt.Errorf("erro ao consultar aceitação existente do documento legal: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// ListAcceptances recupera os registos de aceitação do tenant, mais recentes primeiro
+func (r *LegalConsentRepository) ListAcceptances(ctx context.Context, tenantID uuid.UUID, filter model.LegalAcceptanceFilter) ([]*model.LegalAcceptance, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.ListAcceptances")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + legalAcceptanceColumns + `
+		FROM legal_acceptances
+		WHERE tenant_id = $1
+			AND ($2::UUID IS NULL OR user_id = $2)
+			AND ($3::UUID IS NULL OR document_id = $3)
+			AND ($4::TIMESTAMPTZ IS NULL OR accepted_at >= $4)
+		ORDER BY accepted_at DESC
+		LIMIT $5
+	`
+
+	var acceptances []*model.LegalAcceptance
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, filter.UserID, filter.DocumentID, filter.Since, filter.Limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar aceitações de documentos legais: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			acceptance, err := scanLegalAcceptance(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler aceitação de documento legal: %w", err)
+			}
+			acceptances = append(acceptances, acceptance)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return acceptances, nil
+}
+
+// CountAcceptances conta os usuários ativos do tenant e os que aceitaram a versão do documento
+func (r *LegalConsentRepository) CountAcceptances(ctx context.Context, tenantID, documentID uuid.UUID) (int, int, error) {
+	ctx, span := tracer.Start(ctx, "LegalConsentRepository.CountAcceptances")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("legal_document.id", documentID.String()),
+	)
+
+	query := `
+		SELECT
+			(SELECT count(*) FROM users u WHERE u.tenant_id = $1 AND ` + legalConsentActiveUserCondition + `),
+			(SELECT count(*)
+				FROM legal_acceptances a
+				JOIN users u ON u.id = a.user_id
+				WHERE a.tenant_id = $1 AND a.document_id = $2 AND ` + legalConsentActiveUserCondition + `)
+	`
+
+	var activeUsers, accepted int
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, tenantID, documentID).Scan(&activeUsers, &accepted); err != nil {
+			return fmt.Errorf("erro ao contar aceitações do documento legal: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return 0, 0, err
+	}
+
+	return activeUsers, accepted, nil
+}
+
+// queryDocuments executa uma consulta que retorna as colunas de legalDocumentColumns
+func (r *LegalConsentRepository) queryDocuments(ctx context.Context, query string, args ...interface{}) ([]*model.LegalDocument, error) {
+	var documents []*model.LegalDocument
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar documentos legais: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			document, err := scanLegalDocument(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler documento legal: %w", err)
+			}
+			documents = append(documents, document)
+		}
+		return rows.Err()
+	})
+	return documents, err
+}
+
+// scanLegalDocument lê as colunas de legalDocumentColumns
+func scanLegalDocument(row pgx.Row) (*model.LegalDocument, error) {
+	var (
+		document     model.LegalDocument
+		documentType string
+	)
+	err := row.Scan(
+		&document.ID, &document.TenantID, &documentType, &document.Version, &document.Title, &document.URL,
+		&document.ContentHash, &document.Required, &document.EffectiveAt, &document.PublishedBy, &document.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	document.Type = model.LegalDocumentType(documentType)
+	return &document, nil
+}
+
+// scanLegalAcceptance lê as colunas de legalAcceptanceColumns
+func scanLegalAcceptance(row pgx.Row) (*model.LegalAcceptance, error) {
+	var (
+		acceptance   model.LegalAcceptance
+		documentType string
+	)
+	err := row.Scan(
+		&acceptance.ID, &acceptance.TenantID, &acceptance.UserID, &acceptance.DocumentID, &documentType,
+		&acceptance.Version, &acceptance.DocumentHash, &acceptance.AcceptedAt, &acceptance.IPAddress,
+		&acceptance.UserAgent,
+	)
+	if err != nil {
+		return nil, err
+	}
+	acceptance.DocumentType = model.LegalDocumentType(documentType)
+	return &acceptance, nil
+}
@@ -0,0 +1,674 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório das notificações de login
+const loginNotificationColumns = `
+	id, tenant_id, user_id, reasons, COALESCE(method, ''), COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+	COALESCE(country, ''), contexts, deliveries, response_hash, login_at, created_at, expires_at,
+	responded_at, sessions_ended
+`
+
+// loginContextRecord é a representação gravada de um contexto de login
+type loginContextRecord struct {
+	Kind        model.LoginContextKind `json:"kind"`
+	Fingerprint string                 `json:"fingerprint"`
+}
+
+// LoginNotificationRepository implementa a interface repository.LoginNotificationRepository usando PostgreSQL
+type LoginNotificationRepository struct {
+	db *DB
+}
+
+// NewLoginNotificationRepository cria uma nova instância do LoginNotificationRepository
+func NewLoginNotificationRepository(db *DB) *LoginNotificationRepository {
+	return &LoginNotificationRepository{db: db}
+}
+
+// GetSettings recupera a configuração das notificações do tenant, ou nil se não existir
+func (r *LoginNotificationRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.LoginNotificationSettings, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.GetSettings")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT tenant_id, enabled, channels, COALESCE(market, ''), COALESCE(default_locale, ''), notify_first_login,
+			COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM login_notification_settings
+		WHERE tenant_id = $1
+	`
+
+	var settings *model.LoginNotificationSettings
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var (
+			found    model.LoginNotificationSettings
+			channels []string
+		)
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&found.TenantID, &found.Enabled, &channels, &found.Market, &found.DefaultLocale,
+			&found.NotifyFirstLogin, &found.UpdatedBy, &found.UpdatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar configuração das notificações de login: %w", err)
+		}
+		for _, channel := range channels {
+			found.Channels = append(found.Channels, model.LoginNotificationChannel(channel))
+		}
+		settings = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SaveSettings grava a configuração das notificações do tenant, substituindo a anterior
+func (r *LoginNotificationRepository) SaveSettings(ctx context.Context, settings *model.LoginNotificationSettings) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.SaveSettings")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", settings.TenantID.String()),
+		attribute.Bool("login_notification.enabled", settings.Enabled),
+	)
+
+	channels := make([]string, 0, len(settings.Channels))
+	for _, channel := range settings.Channels {
+		channels = append(channels, string(channel))
+	}
+
+	query := `
+		INSERT INTO login_notification_settings (
+			tenant_id, enabled, channels, market, default_locale, notify_first_login, updated_by, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			channels = EXCLUDED.channels,
+			market = EXCLUDED.market,
+			default_locale = EXCLUDED.default_locale,
+			notify_first_login = EXCLUDED.notify_first_login,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			settings.TenantID, settings.Enabled, channels, settings.Market, settings.DefaultLocale,
+			settings.NotifyFirstLogin, settings.UpdatedBy, settings.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar configuração das notificações de login: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListTemplates recupera os modelos de mensagem do tenant
+func (r *LoginNotificationRepository) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]*model.LoginNotificationTemplate, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.ListTemplates")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT id, tenant_id, kind, channel, market, locale, COALESCE(subject, ''), body,
+			COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM login_notification_templates
+		WHERE tenant_id = $1
+		ORDER BY kind, channel, market, locale
+	`
+
+	var templates []*model.LoginNotificationTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelos de notificação de login: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				template      model.LoginNotificationTemplate
+				kind, channel string
+			)
+			if err := rows.Scan(
+				&template.ID, &template.TenantID, &kind, &channel, &template.Market, &template.Locale,
+				&template.Subject, &template.Body, &template.UpdatedBy, &template.UpdatedAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler modelo de notificação de login: %w", err)
+			}
+			template.Kind = model.LoginNotificationKind(kind)
+			template.Channel = model.LoginNotificationChannel(channel)
+			templates = append(templates, &template)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// SaveTemplate grava o modelo da mensagem, canal, mercado e idioma, substituindo o existente
+func (r *LoginNotificationRepository) SaveTemplate(ctx context.Context, template *model.LoginNotificationTemplate) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.SaveTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", template.TenantID.String()),
+		attribute.String("login_notification.kind", string(template.Kind)),
+		attribute.String("login_notification.channel", string(template.Channel)),
+	)
+
+	query := `
+		INSERT INTO login_notification_templates (
+			id, tenant_id, kind, channel, market, locale, subject, body, updated_by, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+		ON CONFLICT (tenant_id, kind, channel, market, locale) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			body = EXCLUDED.body,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			template.ID, template.TenantID, string(template.Kind), string(template.Channel), template.Market,
+			template.Locale, template.Subject, template.Body, template.UpdatedBy, template.UpdatedAt,
+		).Scan(&template.ID)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar modelo de notificação de login: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTemplate remove um modelo do tenant
+func (r *LoginNotificationRepository) DeleteTemplate(ctx context.Context, tenantID, templateID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.DeleteTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("login_notification.template_id", templateID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `
+			DELETE FROM login_notification_templates WHERE tenant_id = $1 AND id = $2
+		`, tenantID, templateID)
+		if err != nil {
+			return fmt.Errorf("erro ao remover modelo de notificação de login: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrLoginNotificationTemplateNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetRecipient recupera os contactos e o idioma do usuário
+func (r *LoginNotificationRepository) GetRecipient(ctx context.Context, tenantID, userID uuid.UUID) (*model.LoginNotificationRecipient, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.GetRecipient")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `
+		SELECT id, tenant_id, email, COALESCE(phone_number, ''),
+			COALESCE(NULLIF(display_name, ''), TRIM(first_name || ' ' || last_name)),
+			COALESCE(locale, ''), COALESCE(timezone, ''), status
+		FROM users
+		WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+
+	var recipient model.LoginNotificationRecipient
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var status string
+		err := tx.QueryRow(ctx, query, tenantID, userID).Scan(
+			&recipient.UserID, &recipient.TenantID, &recipient.Email, &recipient.PhoneNumber,
+			&recipient.DisplayName, &recipient.Locale, &recipient.Timezone, &status,
+		)
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar contactos do usuário: %w", err)
+		}
+		recipient.Status = model.UserStatus(status)
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &recipient, nil
+}
+
+// TouchLoginContexts regista os contextos do login e retorna os tipos de contexto desconhecidos
+// Os contextos do usuário são bloqueados na transação, para que logins simultâneos no mesmo
+// contexto novo não o considerem conhecido antes de ser gravado
+func (r *LoginNotificationRepository) TouchLoginContexts(ctx context.Context, tenantID, userID uuid.UUID, contexts []model.LoginContextFingerprint, seenAt, staleBefore time.Time) ([]model.LoginContextKind, bool, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.TouchLoginContexts")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	var (
+		unknown    []model.LoginContextKind
+		firstLogin bool
+	)
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT kind, fingerprint FROM login_known_contexts
+			WHERE tenant_id = $1 AND user_id = $2 AND last_seen_at >= $3
+			FOR UPDATE
+		`, tenantID, userID, staleBefore)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar contextos de login conhecidos: %w", err)
+		}
+		known := make(map[loginContextRecord]bool)
+		for rows.Next() {
+			var record loginContextRecord
+			var kind string
+			if err := rows.Scan(&kind, &record.Fingerprint); err != nil {
+				rows.Close()
+				return fmt.Errorf("erro ao ler contexto de login conhecido: %w", err)
+			}
+			record.Kind = model.LoginContextKind(kind)
+			known[record] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("erro ao consultar contextos de login conhecidos: %w", err)
+		}
+		firstLogin = len(known) == 0
+
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM login_known_contexts
+			WHERE tenant_id = $1 AND user_id = $2 AND last_seen_at < $3
+		`, tenantID, userID, staleBefore); err != nil {
+			return fmt.Errorf("erro ao remover contextos de login antigos: %w", err)
+		}
+
+		for _, loginContext := range contexts {
+			if !known[loginContextRecord{Kind: loginContext.Kind, Fingerprint: loginContext.Fingerprint}] {
+				unknown = append(unknown, loginContext.Kind)
+			}
+			_, err := tx.Exec(ctx, `
+				INSERT INTO login_known_contexts (tenant_id, user_id, kind, fingerprint, first_seen_at, last_seen_at)
+				VALUES ($1, $2, $3, $4, $5, $5)
+				ON CONFLICT (tenant_id, user_id, kind, fingerprint) DO UPDATE SET
+					last_seen_at = GREATEST(login_known_contexts.last_seen_at, EXCLUDED.last_seen_at)
+			`, tenantID, userID, string(loginContext.Kind), loginContext.Fingerprint, seenAt)
+			if err != nil {
+				return fmt.Errorf("erro ao registar contexto de login: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, false, err
+	}
+
+	return unknown, firstLogin, nil
+}
+
+// CreateNotification grava uma notificação antes do envio
+func (r *LoginNotificationRepository) CreateNotification(ctx context.Context, notification *model.LoginNotification) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.CreateNotification")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", notification.TenantID.String()),
+		attribute.String("user.id", notification.UserID.String()),
+	)
+
+	reasons := make([]string, 0, len(notification.Reasons))
+	for _, reason := range notification.Reasons {
+		reasons = append(reasons, string(reason))
+	}
+	records := make([]loginContextRecord, 0, len(notification.Contexts))
+	for _, loginContext := range notification.Contexts {
+		records = append(records, loginContextRecord{Kind: loginContext.Kind, Fingerprint: loginContext.Fingerprint})
+	}
+	contexts, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar contextos da notificação de login: %w", err)
+	}
+	deliveries, err := json.Marshal(notification.Deliveries)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar entregas da notificação de login: %w", err)
+	}
+
+	query := `
+		INSERT INTO login_notifications (
+			id, tenant_id, user_id, reasons, method, ip_address, user_agent, country, contexts,
+			deliveries, response_hash, login_at, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9,
+			$10, $11, $12, $13, $14)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			notification.ID, notification.TenantID, notification.UserID, reasons, notification.Method,
+			notification.IPAddress, notification.UserAgent, notification.Country, contexts, deliveries,
+			notification.ResponseHash, notification.LoginAt, notification.CreatedAt, notification.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir notificação de login: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// SaveDeliveries grava o resultado das entregas de uma notificação
+func (r *LoginNotificationRepository) SaveDeliveries(ctx context.Context, tenantID, notificationID uuid.UUID, deliveries []model.LoginNotificationDelivery) error {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.SaveDeliveries")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("login_notification.id", notificationID.String()),
+	)
+
+	encoded, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar entregas da notificação de login: %w", err)
+	}
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `
+			UPDATE login_notifications SET deliveries = $3
+			WHERE tenant_id = $1 AND id = $2
+		`, tenantID, notificationID, encoded)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar entregas da notificação de login: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrLoginNotificationNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetNotification recupera uma notificação do tenant
+func (r *LoginNotificationRepository) GetNotification(ctx context.Context, tenantID, notificationID uuid.UUID) (*model.LoginNotification, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.GetNotification")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("login_notification.id", notificationID.String()),
+	)
+
+	query := `SELECT ` + loginNotificationColumns + `
+		FROM login_notifications
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var notification *model.LoginNotification
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanLoginNotification(tx.QueryRow(ctx, query, tenantID, notificationID))
+		if err == pgx.ErrNoRows {
+			return model.ErrLoginNotificationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		notification = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+// ListNotifications recupera as notificações mais recentes do usuário
+func (r *LoginNotificationRepository) ListNotifications(ctx context.Context, tenantID, userID uuid.UUID, limit int) ([]*model.LoginNotification, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.ListNotifications")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + loginNotificationColumns + `
+		FROM login_notifications
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	notifications := []*model.LoginNotification{}
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID, limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar notificações de login: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			notification, err := scanLoginNotification(rows)
+			if err != nil {
+				return err
+			}
+			notifications = append(notifications, notification)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// RecordNotMeResponse regista a resposta "não fui eu" atomicamente
+// A notificação só é marcada se ainda não tiver sido respondida, para que respostas
+// simultâneas com o mesmo link não criem dois pedidos de redefinição
+func (r *LoginNotificationRepository) RecordNotMeResponse(ctx context.Context, notification *model.LoginNotification, reset *model.CredentialResetToken, respondedAt time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "LoginNotificationRepository.RecordNotMeResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", notification.TenantID.String()),
+		attribute.String("user.id", notification.UserID.String()),
+		attribute.String("login_notification.id", notification.ID.String()),
+	)
+
+	fingerprints := make([]string, 0, len(notification.Contexts))
+	for _, loginContext := range notification.Contexts {
+		fingerprints = append(fingerprints, loginContext.Fingerprint)
+	}
+
+	var sessionsEnded int
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `
+			UPDATE login_notifications SET responded_at = $3
+			WHERE tenant_id = $1 AND id = $2 AND responded_at IS NULL AND expires_at > $3
+		`, notification.TenantID, notification.ID, respondedAt)
+		if err != nil {
+			return fmt.Errorf("erro ao registar resposta à notificação de login: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrInvalidLoginResponseToken
+		}
+
+		result, err = tx.Exec(ctx, `
+			UPDATE session_activity SET ended_at = $3
+			WHERE tenant_id = $1 AND user_id = $2 AND ended_at IS NULL
+		`, notification.TenantID, notification.UserID, respondedAt)
+		if err != nil {
+			return fmt.Errorf("erro ao terminar sessões do usuário: %w", err)
+		}
+		sessionsEnded = int(result.RowsAffected())
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE login_notifications SET sessions_ended = $3
+			WHERE tenant_id = $1 AND id = $2
+		`, notification.TenantID, notification.ID, sessionsEnded); err != nil {
+			return fmt.Errorf("erro ao registar sessões terminadas: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO password_reset_tokens (id, user_id, token, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, reset.ID, reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt); err != nil {
+			return fmt.Errorf("erro ao gravar pedido de redefinição da credencial: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE user_credentials SET password_temp_expiry = $2, updated_at = $2
+			WHERE user_id = $1 AND password_hash IS NOT NULL
+		`, notification.UserID, respondedAt); err != nil {
+			return fmt.Errorf("erro ao expirar a senha do usuário: %w", err)
+		}
+
+		if len(fingerprints) > 0 {
+			if _, err := tx.Exec(ctx, `
+				DELETE FROM login_known_contexts
+				WHERE tenant_id = $1 AND user_id = $2 AND fingerprint = ANY($3)
+			`, notification.TenantID, notification.UserID, fingerprints); err != nil {
+				return fmt.Errorf("erro ao esquecer contextos do login: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return sessionsEnded, nil
+}
+
+// scanLoginNotification lê uma notificação de login
+func scanLoginNotification(row pgx.Row) (*model.LoginNotification, error) {
+	var (
+		notification         model.LoginNotification
+		reasons              []string
+		contexts, deliveries []byte
+	)
+	err := row.Scan(
+		&notification.ID, &notification.TenantID, &notification.UserID, &reasons, &notification.Method,
+		&notification.IPAddress, &notification.UserAgent, &notification.Country, &contexts, &deliveries,
+		&notification.ResponseHash, &notification.LoginAt, &notification.CreatedAt, &notification.ExpiresAt,
+		&notification.RespondedAt, &notification.SessionsEnded,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler notificação de login: %w", err)
+	}
+
+	for _, reason := range reasons {
+		notification.Reasons = append(notification.Reasons, model.LoginNotificationReason(reason))
+	}
+	var records []loginContextRecord
+	if len(contexts) > 0 {
+		if err := json.Unmarshal(contexts, &records); err != nil {
+			return nil, fmt.Errorf("erro ao ler contextos da notificação de login: %w", err)
+		}
+	}
+	for _, record := range records {
+		notification.Contexts = append(notification.Contexts, model.LoginContextFingerprint{Kind: record.Kind, Fingerprint: record.Fingerprint})
+	}
+	notification.Deliveries = []model.LoginNotificationDelivery{}
+	if len(deliveries) > 0 {
+		if err := json.Unmarshal(deliveries, &notification.Deliveries); err != nil {
+			return nil, fmt.Errorf("erro ao ler entregas da notificação de login: %w", err)
+		}
+	}
+	return &notification, nil
+}
@@ -0,0 +1,454 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório das políticas de rede
+// Os blocos CIDR são lidos como texto para manter a notação gravada
+const networkPolicyColumns = `
+	id, tenant_id, name, scope, action, COALESCE(role_code, ''), cidrs::TEXT[], enabled,
+	COALESCE(created_by, '00000000-0000-0000-0000-000000000000'::UUID),
+	COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), created_at, updated_at
+`
+
+const breakGlassColumns = `
+	id, tenant_id, user_id, justification, COALESCE(ticket_ref, ''), granted_by,
+	created_at, expires_at, revoked_at, revoked_by
+`
+
+// NetworkPolicyRepository implementa a interface repository.NetworkPolicyRepository usando PostgreSQL
+type NetworkPolicyRepository struct {
+	db *DB
+}
+
+// NewNetworkPolicyRepository cria uma nova instância do NetworkPolicyRepository
+func NewNetworkPolicyRepository(db *DB) *NetworkPolicyRepository {
+	return &NetworkPolicyRepository{db: db}
+}
+
+// ListPolicies recupera todas as políticas do tenant, ordenadas pelo nome
+func (r *NetworkPolicyRepository) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.NetworkPolicy, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.ListPolicies")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + networkPolicyColumns + `
+		FROM network_policies
+		WHERE tenant_id = $1
+		ORDER BY name
+	`
+
+	var policies []*model.NetworkPolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar políticas de rede: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			policy, err := scanNetworkPolicy(rows)
+			if err != nil {
+				return err
+			}
+			policies = append(policies, policy)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetPolicy recupera uma política do tenant
+func (r *NetworkPolicyRepository) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.NetworkPolicy, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.GetPolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("network_policy.id", policyID.String()),
+	)
+
+	query := `SELECT ` + networkPolicyColumns + `
+		FROM network_policies
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var policy *model.NetworkPolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanNetworkPolicy(tx.QueryRow(ctx, query, tenantID, policyID))
+		if err == pgx.ErrNoRows {
+			return model.ErrNetworkPolicyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		policy = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// CreatePolicy grava uma nova política
+func (r *NetworkPolicyRepository) CreatePolicy(ctx context.Context, policy *model.NetworkPolicy) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.CreatePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.String("network_policy.id", policy.ID.String()),
+	)
+
+	query := `
+		INSERT INTO network_policies (
+			id, tenant_id, name, scope, action, role_code, cidrs, enabled,
+			created_by, updated_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7::CIDR[], $8, $9, $10, $11, $12)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			policy.ID, policy.TenantID, policy.Name, string(policy.Scope), string(policy.Action), policy.RoleCode,
+			policy.CIDRs, policy.Enabled, policy.CreatedBy, policy.UpdatedBy, policy.CreatedAt, policy.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrNetworkPolicyConflict
+			}
+			return fmt.Errorf("erro ao inserir política de rede: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePolicy altera uma política existente
+func (r *NetworkPolicyRepository) UpdatePolicy(ctx context.Context, policy *model.NetworkPolicy) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.UpdatePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.String("network_policy.id", policy.ID.String()),
+	)
+
+	query := `
+		UPDATE network_policies SET
+			name = $3, scope = $4, action = $5, role_code = NULLIF($6, ''), cidrs = $7::CIDR[],
+			enabled = $8, updated_by = $9, updated_at = $10
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			policy.TenantID, policy.ID, policy.Name, string(policy.Scope), string(policy.Action), policy.RoleCode,
+			policy.CIDRs, policy.Enabled, policy.UpdatedBy, policy.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrNetworkPolicyConflict
+			}
+			return fmt.Errorf("erro ao atualizar política de rede: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrNetworkPolicyNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePolicy remove uma política do tenant
+func (r *NetworkPolicyRepository) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.DeletePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("network_policy.id", policyID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM network_policies WHERE tenant_id = $1 AND id = $2`, tenantID, policyID)
+		if err != nil {
+			return fmt.Errorf("erro ao remover política de rede: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrNetworkPolicyNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateBreakGlass grava um novo acesso de emergência
+func (r *NetworkPolicyRepository) CreateBreakGlass(ctx context.Context, override *model.BreakGlassOverride) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.CreateBreakGlass")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", override.TenantID.String()),
+		attribute.String("break_glass.id", override.ID.String()),
+		attribute.String("user.id", override.UserID.String()),
+	)
+
+	query := `
+		INSERT INTO network_break_glass (
+			id, tenant_id, user_id, justification, ticket_ref, granted_by, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			override.ID, override.TenantID, override.UserID, override.Justification, override.TicketRef,
+			override.GrantedBy, override.CreatedAt, override.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir acesso de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeBreakGlass revoga um acesso de emergência ainda não revogado
+func (r *NetworkPolicyRepository) RevokeBreakGlass(ctx context.Context, tenantID, overrideID, revokedBy uuid.UUID, revokedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.RevokeBreakGlass")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("break_glass.id", overrideID.String()),
+	)
+
+	query := `
+		UPDATE network_break_glass SET revoked_at = $3, revoked_by = $4
+		WHERE tenant_id = $1 AND id = $2 AND revoked_at IS NULL
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, tenantID, overrideID, revokedAt, revokedBy)
+		if err != nil {
+			return fmt.Errorf("erro ao revogar acesso de emergência: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrBreakGlassNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListBreakGlass recupera os acessos de emergência do tenant, mais recentes primeiro
+func (r *NetworkPolicyRepository) ListBreakGlass(ctx context.Context, tenantID uuid.UUID, activeAt *time.Time) ([]*model.BreakGlassOverride, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.ListBreakGlass")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Bool("break_glass.active_only", activeAt != nil),
+	)
+
+	query := `SELECT ` + breakGlassColumns + `
+		FROM network_break_glass
+		WHERE tenant_id = $1
+			AND ($2::TIMESTAMPTZ IS NULL OR (revoked_at IS NULL AND expires_at > $2))
+		ORDER BY created_at DESC
+	`
+
+	var overrides []*model.BreakGlassOverride
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, activeAt)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar acessos de emergência: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var override model.BreakGlassOverride
+			if err := rows.Scan(
+				&override.ID, &override.TenantID, &override.UserID, &override.Justification, &override.TicketRef,
+				&override.GrantedBy, &override.CreatedAt, &override.ExpiresAt, &override.RevokedAt, &override.RevokedBy,
+			); err != nil {
+				return fmt.Errorf("erro ao ler acesso de emergência: %w", err)
+			}
+			overrides = append(overrides, &override)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+// RecordBreakGlassUse grava o uso de um acesso de emergência
+func (r *NetworkPolicyRepository) RecordBreakGlassUse(ctx context.Context, use *model.BreakGlassUse) error {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.RecordBreakGlassUse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", use.TenantID.String()),
+		attribute.String("break_glass.id", use.OverrideID.String()),
+	)
+
+	query := `
+		INSERT INTO network_break_glass_uses (
+			id, override_id, tenant_id, user_id, ip_address, method, path, blocked_reason, policy_id, used_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			use.ID, use.OverrideID, use.TenantID, use.UserID, use.IPAddress, use.Method, use.Path,
+			use.BlockedReason, use.PolicyID, use.UsedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao registar uso do acesso de emergência: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListBreakGlassUses recupera os usos de um acesso de emergência, mais recentes primeiro
+func (r *NetworkPolicyRepository) ListBreakGlassUses(ctx context.Context, tenantID, overrideID uuid.UUID, limit int) ([]*model.BreakGlassUse, error) {
+	ctx, span := tracer.Start(ctx, "NetworkPolicyRepository.ListBreakGlassUses")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("break_glass.id", overrideID.String()),
+	)
+
+	query := `
+		SELECT id, override_id, tenant_id, user_id, ip_address, COALESCE(method, ''), COALESCE(path, ''),
+			blocked_reason, policy_id, used_at
+		FROM network_break_glass_uses
+		WHERE tenant_id = $1 AND override_id = $2
+		ORDER BY used_at DESC
+		LIMIT $3
+	`
+
+	var uses []*model.BreakGlassUse
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, overrideID, limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usos do acesso de emergência: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var use model.BreakGlassUse
+			if err := rows.Scan(
+				&use.ID, &use.OverrideID, &use.TenantID, &use.UserID, &use.IPAddress, &use.Method, &use.Path,
+				&use.BlockedReason, &use.PolicyID, &use.UsedAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler uso do acesso de emergência: %w", err)
+			}
+			uses = append(uses, &use)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return uses, nil
+}
+
+// scanNetworkPolicy lê uma política de uma linha
+func scanNetworkPolicy(row pgx.Row) (*model.NetworkPolicy, error) {
+	var (
+		policy        model.NetworkPolicy
+		scope, action string
+	)
+	err := row.Scan(
+		&policy.ID, &policy.TenantID, &policy.Name, &scope, &action, &policy.RoleCode, &policy.CIDRs,
+		&policy.Enabled, &policy.CreatedBy, &policy.UpdatedBy, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler política de rede: %w", err)
+	}
+	policy.Scope = model.NetworkPolicyScope(scope)
+	policy.Action = model.NetworkPolicyAction(action)
+	return &policy, nil
+}
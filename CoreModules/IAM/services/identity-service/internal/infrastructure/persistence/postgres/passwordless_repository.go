@@ -0,0 +1,372 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório da autenticação sem senha
+// Os desafios pedidos para emails sem conta têm user_id nulo, lido como uuid.Nil
+const passwordlessChallengeColumns = `
+	id, tenant_id, COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::UUID), email, method,
+	secret_hash, COALESCE(device_hash, ''), COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+	attempts, max_attempts, created_at, expires_at, consumed_at
+`
+
+// PasswordlessRepository implementa a interface repository.PasswordlessRepository usando PostgreSQL
+type PasswordlessRepository struct {
+	db *DB
+}
+
+// NewPasswordlessRepository cria uma nova instância do PasswordlessRepository
+func NewPasswordlessRepository(db *DB) *PasswordlessRepository {
+	return &PasswordlessRepository{db: db}
+}
+
+// GetSettings recupera a configuração sem senha do tenant, ou nil se não existir
+func (r *PasswordlessRepository) GetSettings(ctx context.Context, tenantID uuid.UUID) (*model.PasswordlessSettings, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.GetSettings")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT tenant_id, enabled, methods, device_binding, COALESCE(market, ''), financial_tenant,
+			COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM passwordless_settings
+		WHERE tenant_id = $1
+	`
+
+	var settings *model.PasswordlessSettings
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var (
+			found         model.PasswordlessSettings
+			methods       []string
+			deviceBinding string
+		)
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&found.TenantID, &found.Enabled, &methods, &deviceBinding, &found.Market,
+			&found.FinancialTenant, &found.UpdatedBy, &found.UpdatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar configuração sem senha: %w", err)
+		}
+		found.DeviceBinding = model.PasswordlessDeviceBinding(deviceBinding)
+		for _, method := range methods {
+			found.Methods = append(found.Methods, model.PasswordlessMethod(method))
+		}
+		settings = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SaveSettings grava a configuração sem senha do tenant, substituindo a anterior
+func (r *PasswordlessRepository) SaveSettings(ctx context.Context, settings *model.PasswordlessSettings) error {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.SaveSettings")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", settings.TenantID.String()),
+		attribute.Bool("passwordless.enabled", settings.Enabled),
+	)
+
+	methods := make([]string, 0, len(settings.Methods))
+	for _, method := range settings.Methods {
+		methods = append(methods, string(method))
+	}
+
+	query := `
+		INSERT INTO passwordless_settings (
+			tenant_id, enabled, methods, device_binding, market, financial_tenant, updated_by, updated_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			methods = EXCLUDED.methods,
+			device_binding = EXCLUDED.device_binding,
+			market = EXCLUDED.market,
+			financial_tenant = EXCLUDED.financial_tenant,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			settings.TenantID, settings.Enabled, methods, string(settings.DeviceBinding), settings.Market,
+			settings.FinancialTenant, settings.UpdatedBy, settings.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar configuração sem senha: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// FindUserByEmail recupera o usuário do tenant com o email indicado
+func (r *PasswordlessRepository) FindUserByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.FindUserByEmail")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND LOWER(email) = LOWER($2) AND deleted_at IS NULL
+	`
+
+	var user *model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		user, err = scanLifecycleUser(tx.QueryRow(ctx, query, tenantID, email))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuário por email: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUser recupera um usuário do tenant
+func (r *PasswordlessRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.GetUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+
+	var user *model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		user, err = scanLifecycleUser(tx.QueryRow(ctx, query, tenantID, userID))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuário: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CountRecentChallenges conta os desafios recentes do tenant para o email e para o endereço IP
+func (r *PasswordlessRepository) CountRecentChallenges(ctx context.Context, tenantID uuid.UUID, email, ipAddress string, since time.Time) (int, int, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.CountRecentChallenges")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE email = $2),
+			COUNT(*) FILTER (WHERE $3 <> '' AND ip_address = $3)
+		FROM passwordless_challenges
+		WHERE tenant_id = $1 AND created_at >= $4 AND (email = $2 OR ($3 <> '' AND ip_address = $3))
+	`
+
+	var byEmail, byIP int
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, tenantID, email, ipAddress, since).Scan(&byEmail, &byIP); err != nil {
+			return fmt.Errorf("erro ao contar desafios sem senha: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return 0, 0, err
+	}
+
+	return byEmail, byIP, nil
+}
+
+// CreateChallenge grava um novo desafio
+func (r *PasswordlessRepository) CreateChallenge(ctx context.Context, challenge *model.PasswordlessChallenge) error {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.CreateChallenge")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("passwordless_challenge.id", challenge.ID.String()),
+		attribute.String("passwordless_challenge.method", string(challenge.Method)),
+		attribute.String("tenant.id", challenge.TenantID.String()),
+	)
+
+	query := `
+		INSERT INTO passwordless_challenges (
+			id, tenant_id, user_id, email, method, secret_hash, device_hash, ip_address, user_agent,
+			attempts, max_attempts, created_at, expires_at
+		) VALUES (
+			$1, $2, NULLIF($3, '00000000-0000-0000-0000-000000000000'::UUID), $4, $5, $6,
+			NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13
+		)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			challenge.ID, challenge.TenantID, challenge.UserID, challenge.Email, string(challenge.Method),
+			challenge.SecretHash, challenge.DeviceHash, challenge.IPAddress, challenge.UserAgent,
+			challenge.Attempts, challenge.MaxAttempts, challenge.CreatedAt, challenge.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir desafio sem senha: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimAttempt regista uma tentativa de verificação do desafio e retorna-o
+func (r *PasswordlessRepository) ClaimAttempt(ctx context.Context, challengeID uuid.UUID, now time.Time) (*model.PasswordlessChallenge, error) {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.ClaimAttempt")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("passwordless_challenge.id", challengeID.String()))
+
+	// A atualização condicional impede que tentativas concorrentes ultrapassem o limite
+	query := `
+		UPDATE passwordless_challenges
+		SET attempts = attempts + 1
+		WHERE id = $1 AND consumed_at IS NULL AND expires_at > $2 AND attempts < max_attempts
+		RETURNING ` + passwordlessChallengeColumns
+
+	var challenge *model.PasswordlessChallenge
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		challenge, err = scanPasswordlessChallenge(tx.QueryRow(ctx, query, challengeID, now))
+		if err == pgx.ErrNoRows {
+			return model.ErrPasswordlessChallengeNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao registar tentativa sem senha: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// ConsumeChallenge marca o desafio como consumido e regista o login do usuário numa única transação
+func (r *PasswordlessRepository) ConsumeChallenge(ctx context.Context, challengeID uuid.UUID, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "PasswordlessRepository.ConsumeChallenge")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("passwordless_challenge.id", challengeID.String()))
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		// A atualização condicional garante que cada desafio é consumido uma única vez
+		var tenantID, userID uuid.UUID
+		err := tx.QueryRow(ctx, `
+			UPDATE passwordless_challenges
+			SET consumed_at = $2
+			WHERE id = $1 AND consumed_at IS NULL AND expires_at > $2 AND user_id IS NOT NULL
+			RETURNING tenant_id, user_id
+		`, challengeID, now).Scan(&tenantID, &userID)
+		if err == pgx.ErrNoRows {
+			return model.ErrPasswordlessChallengeNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consumir desafio sem senha: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE users
+			SET login_count = login_count + 1, last_login_at = $3
+			WHERE tenant_id = $1 AND id = $2
+		`, tenantID, userID, now)
+		if err != nil {
+			return fmt.Errorf("erro ao registar login sem senha do usuário: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanPasswordlessChallenge lê as colunas de passwordlessChallengeColumns
+func scanPasswordlessChallenge(row pgx.Row) (*model.PasswordlessChallenge, error) {
+	var (
+		challenge model.PasswordlessChallenge
+		method    string
+	)
+	err := row.Scan(
+		&challenge.ID, &challenge.TenantID, &challenge.UserID, &challenge.Email, &method,
+		&challenge.SecretHash, &challenge.DeviceHash, &challenge.IPAddress, &challenge.UserAgent,
+		&challenge.Attempts, &challenge.MaxAttempts, &challenge.CreatedAt, &challenge.ExpiresAt,
+		&challenge.ConsumedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	challenge.Method = model.PasswordlessMethod(method)
+	return &challenge, nil
+}
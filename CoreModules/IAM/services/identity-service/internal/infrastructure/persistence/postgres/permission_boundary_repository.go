@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório dos limites de permissões, com o código atual da função titular
+const permissionBoundaryColumns = `
+	b.id, b.tenant_id, b.subject_type, b.subject_id, COALESCE(r.code, ''), b.name,
+	COALESCE(b.description, ''), b.permissions,
+	COALESCE(b.created_by, '00000000-0000-0000-0000-000000000000'::UUID),
+	COALESCE(b.updated_by, '00000000-0000-0000-0000-000000000000'::UUID), b.created_at, b.updated_at
+`
+
+// permissionBoundarySource junta as funções titulares, para obter o código atual
+const permissionBoundarySource = `
+	permission_boundaries b
+	LEFT JOIN roles r ON b.subject_type = 'role' AND r.id = b.subject_id AND r.tenant_id = b.tenant_id
+`
+
+// PermissionBoundaryRepository implementa a interface repository.PermissionBoundaryRepository usando PostgreSQL
+type PermissionBoundaryRepository struct {
+	db *DB
+}
+
+// NewPermissionBoundaryRepository cria uma nova instância do PermissionBoundaryRepository
+func NewPermissionBoundaryRepository(db *DB) *PermissionBoundaryRepository {
+	return &PermissionBoundaryRepository{db: db}
+}
+
+// List recupera os limites do tenant, com o código atual das funções titulares, ordenados pelo nome
+func (r *PermissionBoundaryRepository) List(ctx context.Context, tenantID uuid.UUID) ([]*model.PermissionBoundary, error) {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryRepository.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + permissionBoundaryColumns + `
+		FROM ` + permissionBoundarySource + `
+		WHERE b.tenant_id = $1
+		ORDER BY b.name, b.id
+	`
+
+	var boundaries []*model.PermissionBoundary
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar limites de permissões: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			boundary, err := scanPermissionBoundary(rows)
+			if err != nil {
+				return err
+			}
+			boundaries = append(boundaries, boundary)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return boundaries, nil
+}
+
+// Get recupera um limite do tenant
+func (r *PermissionBoundaryRepository) Get(ctx context.Context, tenantID, boundaryID uuid.UUID) (*model.PermissionBoundary, error) {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("permission_boundary.id", boundaryID.String()),
+	)
+
+	query := `SELECT ` + permissionBoundaryColumns + `
+		FROM ` + permissionBoundarySource + `
+		WHERE b.tenant_id = $1 AND b.id = $2
+	`
+
+	var boundary *model.PermissionBoundary
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanPermissionBoundary(tx.QueryRow(ctx, query, tenantID, boundaryID))
+		if err == pgx.ErrNoRows {
+			return model.ErrPermissionBoundaryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		boundary = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return boundary, nil
+}
+
+// Create grava um novo limite, verificando que a função ou o usuário titular existe no tenant
+func (r *PermissionBoundaryRepository) Create(ctx context.Context, boundary *model.PermissionBoundary) error {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", boundary.TenantID.String()),
+		attribute.String("permission_boundary.subject_type", string(boundary.SubjectType)),
+		attribute.String("permission_boundary.subject_id", boundary.SubjectID.String()),
+	)
+
+	subjectQuery := `SELECT '' FROM users WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL`
+	if boundary.SubjectType == model.PermissionBoundarySubjectRole {
+		subjectQuery = `SELECT code FROM roles WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL`
+	}
+
+	query := `
+		INSERT INTO permission_boundaries (
+			id, tenant_id, subject_type, subject_id, name, description, permissions,
+			created_by, updated_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), COALESCE($7::TEXT[], '{}'), $8, $9, $10, $11)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var roleCode string
+		err := tx.QueryRow(ctx, subjectQuery, boundary.TenantID, boundary.SubjectID).Scan(&roleCode)
+		if err == pgx.ErrNoRows {
+			return model.ErrPermissionBoundarySubjectNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao verificar titular do limite de permissões: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, query,
+			boundary.ID, boundary.TenantID, string(boundary.SubjectType), boundary.SubjectID, boundary.Name,
+			boundary.Description, boundary.Permissions, boundary.CreatedBy, boundary.UpdatedBy,
+			boundary.CreatedAt, boundary.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrPermissionBoundaryConflict
+			}
+			return fmt.Errorf("erro ao inserir limite de permissões: %w", err)
+		}
+		boundary.RoleCode = roleCode
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Update altera o nome, a descrição e os padrões de um limite
+func (r *PermissionBoundaryRepository) Update(ctx context.Context, boundary *model.PermissionBoundary) error {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryRepository.Update")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", boundary.TenantID.String()),
+		attribute.String("permission_boundary.id", boundary.ID.String()),
+	)
+
+	query := `
+		UPDATE permission_boundaries SET
+			name = $3,
+			description = NULLIF($4, ''),
+			permissions = COALESCE($5::TEXT[], '{}'),
+			updated_by = $6,
+			updated_at = $7
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query,
+			boundary.TenantID, boundary.ID, boundary.Name, boundary.Description, boundary.Permissions,
+			boundary.UpdatedBy, boundary.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar limite de permissões: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrPermissionBoundaryNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete remove um limite
+func (r *PermissionBoundaryRepository) Delete(ctx context.Context, tenantID, boundaryID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "PermissionBoundaryRepository.Delete")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("permission_boundary.id", boundaryID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, `DELETE FROM permission_boundaries WHERE tenant_id = $1 AND id = $2`, tenantID, boundaryID)
+		if err != nil {
+			return fmt.Errorf("erro ao remover limite de permissões: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrPermissionBoundaryNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanPermissionBoundary lê um limite de permissões
+func scanPermissionBoundary(row pgx.Row) (*model.PermissionBoundary, error) {
+	var boundary model.PermissionBoundary
+	var subjectType string
+	err := row.Scan(
+		&boundary.ID, &boundary.TenantID, &subjectType, &boundary.SubjectID, &boundary.RoleCode, &boundary.Name,
+		&boundary.Description, &boundary.Permissions, &boundary.CreatedBy, &boundary.UpdatedBy,
+		&boundary.CreatedAt, &boundary.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler limite de permissões: %w", err)
+	}
+	boundary.SubjectType = model.PermissionBoundarySubjectType(subjectType)
+	if boundary.Permissions == nil {
+		boundary.Permissions = []string{}
+	}
+	return &boundary, nil
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas gravadas e lidas pelo repositório de decisões de autorização
+var permissionDecisionColumns = []string{
+	"id", "tenant_id", "user_id", "action", "resource", "allowed",
+	"policy_path", "request_id", "client_ip", "sample_rate", "decided_at",
+}
+
+// Número máximo de decisões devolvidas quando o filtro não indica limite
+const defaultPermissionDecisionListLimit = 100
+
+// PermissionDecisionRepository implementa a interface repository.PermissionDecisionRepository usando PostgreSQL
+type PermissionDecisionRepository struct {
+	db *DB
+}
+
+// NewPermissionDecisionRepository cria uma nova instância do PermissionDecisionRepository
+func NewPermissionDecisionRepository(db *DB) *PermissionDecisionRepository {
+	return &PermissionDecisionRepository{db: db}
+}
+
+// AppendBatch grava um lote de decisões com COPY, numa única transação
+func (r *PermissionDecisionRepository) AppendBatch(ctx context.Context, decisions []*model.PermissionDecision) error {
+	ctx, span := tracer.Start(ctx, "PermissionDecisionRepository.AppendBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("permission_decision.count", len(decisions)))
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	rows := make([][]interface{}, 0, len(decisions))
+	for _, decision := range decisions {
+		rows = append(rows, []interface{}{
+			decision.ID, decision.TenantID, decision.UserID, decision.Action, decision.Resource, decision.Allowed,
+			nullIfEmpty(decision.PolicyPath), nullIfEmpty(decision.RequestID), nullIfEmpty(decision.ClientIP),
+			decision.SampleRate, decision.DecidedAt,
+		})
+	}
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"permission_decisions"}, permissionDecisionColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("erro ao gravar decisões de autorização: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// List recupera as decisões do tenant que satisfazem o filtro
+func (r *PermissionDecisionRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.PermissionDecisionFilter) ([]*model.PermissionDecision, error) {
+	ctx, span := tracer.Start(ctx, "PermissionDecisionRepository.List")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.resource", filter.Resource),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.UserID != nil {
+		addCondition("user_id = $%d", *filter.UserID)
+	}
+	if resource, prefix := filter.ResourcePrefix(); prefix {
+		addCondition(`resource LIKE $%d ESCAPE '\'`, escapeLikePattern(resource)+"%")
+	} else if resource != "" {
+		addCondition("resource = $%d", resource)
+	}
+	if filter.Allowed != nil {
+		addCondition("allowed = $%d", *filter.Allowed)
+	}
+	if filter.From != nil {
+		addCondition("decided_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("decided_at < $%d", *filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPermissionDecisionListLimit
+	}
+	args = append(args, limit)
+
+	query := `SELECT id, tenant_id, user_id, action, resource, allowed,
+			COALESCE(policy_path, ''), COALESCE(request_id, ''), COALESCE(client_ip, ''), sample_rate, decided_at
+		FROM permission_decisions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY decided_at DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	var decisions []*model.PermissionDecision
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar decisões de autorização: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var decision model.PermissionDecision
+			err := rows.Scan(
+				&decision.ID, &decision.TenantID, &decision.UserID, &decision.Action, &decision.Resource, &decision.Allowed,
+				&decision.PolicyPath, &decision.RequestID, &decision.ClientIP, &decision.SampleRate, &decision.DecidedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao ler decisão de autorização: %w", err)
+			}
+			decisions = append(decisions, &decision)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return decisions, nil
+}
+
+// nullIfEmpty converte textos vazios em NULL
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// escapeLikePattern escapa os caracteres especiais de um padrão LIKE
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
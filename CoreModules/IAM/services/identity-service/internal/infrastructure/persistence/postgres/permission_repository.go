@@ -12,15 +12,12 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -32,11 +29,12 @@ import (
 
 // PermissionRepository implementação PostgreSQL do repositório de permissões
 type PermissionRepository struct {
-	db *pgxpool.Pool
+	db *DB
 }
 
 // NewPermissionRepository cria uma nova instância do repositório de permissões PostgreSQL
-func NewPermissionRepository(db *pgxpool.Pool) *PermissionRepository {
+// As consultas passam pelos métodos guardados de DB, que as recusam sem tenant no contexto
+func NewPermissionRepository(db *DB) *PermissionRepository {
 	return &PermissionRepository{
 		db: db,
 	}
@@ -336,54 +334,38 @@ func (r *PermissionRepository) HardDelete(ctx context.Context, tenantID, id uuid
 	))
 	defer span.End()
 
-	// Iniciar uma transação para garantir atomicidade na remoção de registros relacionados
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("falha ao iniciar transação: %w", err)
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
-	}()
+	// Remover os registros relacionados e a permissão numa transação para garantir atomicidade
+	return r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		// Remover associações com funções
+		deleteRoleQuery := `DELETE FROM iam.role_permissions WHERE permission_id = $1 AND tenant_id = $2`
+		if _, err := tx.Exec(ctx, deleteRoleQuery, id, tenantID); err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("permission_id", id.String()).
+				Msg("Erro ao remover associações com funções durante exclusão permanente")
 
-	// Remover associações com funções
-	deleteRoleQuery := `DELETE FROM iam.role_permissions WHERE permission_id = $1 AND tenant_id = $2`
-	_, err = tx.Exec(ctx, deleteRoleQuery, id, tenantID)
-	if err != nil {
-		log.Error().Err(err).
-			Str("tenant_id", tenantID.String()).
-			Str("permission_id", id.String()).
-			Msg("Erro ao remover associações com funções durante exclusão permanente")
-		
-		return fmt.Errorf("falha ao remover associações com funções: %w", err)
-	}
+			return fmt.Errorf("falha ao remover associações com funções: %w", err)
+		}
 
-	// Remover a própria permissão
-	deletePermQuery := `DELETE FROM iam.permissions WHERE id = $1 AND tenant_id = $2`
-	result, err := tx.Exec(ctx, deletePermQuery, id, tenantID)
-	if err != nil {
-		log.Error().Err(err).
-			Str("tenant_id", tenantID.String()).
-			Str("permission_id", id.String()).
-			Msg("Erro ao excluir permanentemente permissão")
-		
-		return fmt.Errorf("falha ao excluir permanentemente permissão: %w", err)
-	}
+		// Remover a própria permissão
+		deletePermQuery := `DELETE FROM iam.permissions WHERE id = $1 AND tenant_id = $2`
+		result, err := tx.Exec(ctx, deletePermQuery, id, tenantID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("permission_id", id.String()).
+				Msg("Erro ao excluir permanentemente permissão")
 
-	// Verificar se alguma linha foi afetada
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return repository.ErrPermissionNotFound
-	}
+			return fmt.Errorf("falha ao excluir permanentemente permissão: %w", err)
+		}
 
-	// Confirmar a transação
-	err = tx.Commit(ctx)
-	if err != nil {
-		return fmt.Errorf("falha ao confirmar exclusão permanente da permissão: %w", err)
-	}
+		// Verificar se alguma linha foi afetada
+		if result.RowsAffected() == 0 {
+			return repository.ErrPermissionNotFound
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // FindAll recupera permissões com base em filtros e paginação
@@ -701,10 +683,72 @@ func (r *PermissionRepository) GetUserDirectPermissions(ctx context.Context, ten
 		return nil, repository.ErrUserNotFound
 	}
 
-	// Em uma implementação inicial, podemos não ter permissões diretas
-	// Este é um placeholder para futura implementação de permissões diretas por usuário
-	// Retornamos uma lista vazia por enquanto
-	return []*model.Permission{}, nil
+	now := time.Now().UTC()
+
+	// Permissões concedidas diretamente, por exemplo na aprovação de um pedido de acesso
+	query := `
+		SELECT p.id, p.tenant_id, p.code, p.name, p.description, 
+		       p.resource_type, p.resource_id, p.action,
+		       p.is_active, p.is_system, p.created_at, p.updated_at, 
+		       p.created_by, p.updated_by, p.deleted_at, p.deleted_by, p.metadata
+		FROM iam.permissions p
+		JOIN iam.user_permissions up ON p.id = up.permission_id AND p.tenant_id = up.tenant_id
+		WHERE up.user_id = $1 
+		  AND up.tenant_id = $2 
+		  AND p.is_active = true 
+		  AND p.deleted_at IS NULL
+		  AND (up.expires_at IS NULL OR up.expires_at > $3)
+		ORDER BY p.code ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, tenantID, now)
+	if err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("user_id", userID.String()).
+			Msg("Erro ao buscar permissões diretas de usuário")
+		return nil, fmt.Errorf("falha ao buscar permissões diretas de usuário: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make([]*model.Permission, 0)
+	for rows.Next() {
+		var entity permissionEntity
+		err := rows.Scan(
+			&entity.ID, &entity.TenantID, &entity.Code, &entity.Name, &entity.Description, 
+			&entity.ResourceType, &entity.ResourceID, &entity.Action,
+			&entity.IsActive, &entity.IsSystem, &entity.CreatedAt, &entity.UpdatedAt,
+			&entity.CreatedBy, &entity.UpdatedBy, &entity.DeletedAt, &entity.DeletedBy, &entity.Metadata,
+		)
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("user_id", userID.String()).
+				Msg("Erro ao escanear dados de permissão")
+			return nil, fmt.Errorf("falha ao escanear dados de permissão: %w", err)
+		}
+
+		permission, err := mapPermissionToDomain(entity)
+		if err != nil {
+			log.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("permission_id", entity.ID.String()).
+				Msg("Erro ao mapear entidade para modelo de domínio")
+			return nil, fmt.Errorf("falha ao mapear entidade para modelo de domínio: %w", err)
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("user_id", userID.String()).
+			Msg("Erro ao iterar resultados de permissões")
+		return nil, fmt.Errorf("falha ao iterar resultados de permissões: %w", err)
+	}
+
+	return permissions, nil
 }
 
 // GetUserRolePermissions obtém permissões atribuídas a um usuário através de funções
@@ -913,10 +957,32 @@ func (r *PermissionRepository) CheckUserPermission(ctx context.Context, tenantID
 		return true, nil
 	}
 
-	// Verificar permissões diretas do usuário (implementação futura)
-	// Este é um placeholder para futura implementação
+	// Verificar permissões concedidas diretamente ao usuário
+	directQuery := `
+		SELECT EXISTS (
+			SELECT 1 
+			FROM iam.permissions p
+			JOIN iam.user_permissions up ON p.id = up.permission_id AND p.tenant_id = up.tenant_id
+			WHERE up.user_id = $1 
+			  AND up.tenant_id = $2 
+			  AND p.code = $3
+			  AND p.is_active = true 
+			  AND p.deleted_at IS NULL
+			  AND (up.expires_at IS NULL OR up.expires_at > $4)
+		)
+	`
+
+	err = r.db.QueryRow(ctx, directQuery, userID, tenantID, permissionCode, now).Scan(&hasPermission)
+	if err != nil {
+		log.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("user_id", userID.String()).
+			Str("permission_code", permissionCode).
+			Msg("Erro ao verificar permissão direta de usuário")
+		return false, fmt.Errorf("falha ao verificar permissão de usuário: %w", err)
+	}
 
-	return false, nil
+	return hasPermission, nil
 }
 
 // CheckUserResourcePermission verifica se um usuário tem uma permissão para um recurso específico
@@ -0,0 +1,514 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório das campanhas de recertificação
+const recertificationCampaignColumns = `
+	id, tenant_id, name, COALESCE(description, ''), role_ids::TEXT[], user_ids::TEXT[], default_reviewer_id,
+	escalation_reviewer_id, status, item_count, escalate_at, deadline, escalated_at, created_by, created_at,
+	completed_at, cancelled_by, cancelled_at, COALESCE(cancel_reason, '')
+`
+
+const recertificationItemColumns = `
+	id, tenant_id, campaign_id, user_id, role_id, role_code, role_name, assigned_at, assigned_by,
+	assignment_expires_at, reviewer_id, status, escalated, escalated_at, decided_by, decided_at,
+	COALESCE(comment, ''), auto_revoked, revoked_at, COALESCE(revocation_error, '')
+`
+
+// Colunas gravadas com COPY na criação dos itens de uma campanha
+var recertificationItemCopyColumns = []string{
+	"id", "tenant_id", "campaign_id", "user_id", "role_id", "role_code", "role_name", "assigned_at",
+	"assigned_by", "assignment_expires_at", "reviewer_id", "status",
+}
+
+// RecertificationRepository implementa a interface repository.RecertificationRepository usando PostgreSQL
+type RecertificationRepository struct {
+	db *DB
+}
+
+// NewRecertificationRepository cria uma nova instância do RecertificationRepository
+func NewRecertificationRepository(db *DB) *RecertificationRepository {
+	return &RecertificationRepository{db: db}
+}
+
+// CreateCampaign grava uma nova campanha e os seus itens na mesma transação
+func (r *RecertificationRepository) CreateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, items []*model.RecertificationItem) error {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.CreateCampaign")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", campaign.TenantID.String()),
+		attribute.String("recertification_campaign.id", campaign.ID.String()),
+		attribute.Int("recertification_item.count", len(items)),
+	)
+
+	query := `
+		INSERT INTO recertification_campaigns (
+			id, tenant_id, name, description, role_ids, user_ids, default_reviewer_id, escalation_reviewer_id,
+			status, item_count, escalate_at, deadline, created_by, created_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5::UUID[], $6::UUID[], $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	rows := make([][]interface{}, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []interface{}{
+			item.ID, item.TenantID, item.CampaignID, item.UserID, item.RoleID, item.RoleCode, item.RoleName,
+			item.AssignedAt, item.AssignedBy, item.AssignmentExpiresAt, item.ReviewerID, string(item.Status),
+		})
+	}
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			campaign.ID, campaign.TenantID, campaign.Name, campaign.Description, uuidStrings(campaign.RoleIDs),
+			uuidStrings(campaign.UserIDs), campaign.DefaultReviewerID, campaign.EscalationReviewerID,
+			string(campaign.Status), campaign.ItemCount, campaign.EscalateAt, campaign.Deadline,
+			campaign.CreatedBy, campaign.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir campanha de recertificação: %w", err)
+		}
+
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"recertification_items"}, recertificationItemCopyColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("erro ao gravar itens de recertificação: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCampaign recupera uma campanha do tenant
+func (r *RecertificationRepository) GetCampaign(ctx context.Context, tenantID, campaignID uuid.UUID) (*model.RecertificationCampaign, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.GetCampaign")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_campaign.id", campaignID.String()),
+	)
+
+	query := `SELECT ` + recertificationCampaignColumns + `
+		FROM recertification_campaigns
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var campaign *model.RecertificationCampaign
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanRecertificationCampaign(tx.QueryRow(ctx, query, tenantID, campaignID))
+		if err == pgx.ErrNoRows {
+			return model.ErrRecertificationCampaignNotFound
+		}
+		if err != nil {
+			return err
+		}
+		campaign = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// ListCampaigns recupera as campanhas do tenant, mais recentes primeiro
+func (r *RecertificationRepository) ListCampaigns(ctx context.Context, tenantID uuid.UUID, status model.RecertificationCampaignStatus) ([]*model.RecertificationCampaign, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.ListCampaigns")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_campaign.status", string(status)),
+	)
+
+	query := `SELECT ` + recertificationCampaignColumns + `
+		FROM recertification_campaigns
+		WHERE tenant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+
+	campaigns, err := r.queryCampaigns(ctx, query, tenantID, string(status))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaign grava a campanha que estava no estado from
+func (r *RecertificationRepository) UpdateCampaign(ctx context.Context, campaign *model.RecertificationCampaign, from model.RecertificationCampaignStatus) error {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.UpdateCampaign")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", campaign.TenantID.String()),
+		attribute.String("recertification_campaign.id", campaign.ID.String()),
+		attribute.String("recertification_campaign.from", string(from)),
+		attribute.String("recertification_campaign.status", string(campaign.Status)),
+	)
+
+	query := `
+		UPDATE recertification_campaigns SET
+			status = $4, escalated_at = $5, completed_at = $6, cancelled_by = $7, cancelled_at = $8,
+			cancel_reason = NULLIF($9, '')
+		WHERE tenant_id = $1 AND id = $2 AND status = $3
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			campaign.TenantID, campaign.ID, string(from), string(campaign.Status), campaign.EscalatedAt,
+			campaign.CompletedAt, campaign.CancelledBy, campaign.CancelledAt, campaign.CancelReason,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar campanha de recertificação: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrRecertificationCampaignState
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListDueCampaigns recupera, de todos os tenants, as campanhas ativas a escalar ou com o prazo terminado
+func (r *RecertificationRepository) ListDueCampaigns(ctx context.Context, now time.Time, limit int) ([]*model.RecertificationCampaign, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.ListDueCampaigns")
+	defer span.End()
+
+	query := `SELECT ` + recertificationCampaignColumns + `
+		FROM recertification_campaigns
+		WHERE status = 'active'
+			AND (deadline <= $1 OR (escalate_at <= $1 AND escalated_at IS NULL))
+		ORDER BY deadline
+		LIMIT $2
+	`
+
+	campaigns, err := r.queryCampaigns(ctx, query, now, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("recertification_campaign.count", len(campaigns)))
+	return campaigns, nil
+}
+
+// GetItem recupera um item de recertificação do tenant
+func (r *RecertificationRepository) GetItem(ctx context.Context, tenantID, itemID uuid.UUID) (*model.RecertificationItem, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.GetItem")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_item.id", itemID.String()),
+	)
+
+	query := `SELECT ` + recertificationItemColumns + `
+		FROM recertification_items
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var item *model.RecertificationItem
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanRecertificationItem(tx.QueryRow(ctx, query, tenantID, itemID))
+		if err == pgx.ErrNoRows {
+			return model.ErrRecertificationItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+		item = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ListItems recupera os itens do tenant que satisfazem o filtro, ordenados pela função e pelo usuário
+func (r *RecertificationRepository) ListItems(ctx context.Context, tenantID uuid.UUID, filter model.RecertificationItemFilter) ([]*model.RecertificationItem, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.ListItems")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(filter.Status)),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	if filter.CampaignID != nil {
+		args = append(args, *filter.CampaignID)
+		conditions = append(conditions, fmt.Sprintf("campaign_id = $%d", len(args)))
+	}
+	if filter.ReviewerID != nil {
+		args = append(args, *filter.ReviewerID)
+		conditions = append(conditions, fmt.Sprintf("reviewer_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + recertificationItemColumns + `
+		FROM recertification_items
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY role_code, user_id
+	`
+
+	items, err := r.queryItems(ctx, query, args...)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateItem grava o item que estava no estado from
+func (r *RecertificationRepository) UpdateItem(ctx context.Context, item *model.RecertificationItem, from model.RecertificationItemStatus) error {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.UpdateItem")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", item.TenantID.String()),
+		attribute.String("recertification_item.id", item.ID.String()),
+		attribute.String("recertification_item.from", string(from)),
+		attribute.String("recertification_item.status", string(item.Status)),
+	)
+
+	query := `
+		UPDATE recertification_items SET
+			reviewer_id = $4, status = $5, escalated = $6, escalated_at = $7, decided_by = $8, decided_at = $9,
+			comment = NULLIF($10, ''), auto_revoked = $11, revoked_at = $12, revocation_error = NULLIF($13, '')
+		WHERE tenant_id = $1 AND id = $2 AND status = $3
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			item.TenantID, item.ID, string(from), item.ReviewerID, string(item.Status), item.Escalated,
+			item.EscalatedAt, item.DecidedBy, item.DecidedAt, item.Comment, item.AutoRevoked, item.RevokedAt,
+			item.RevocationError,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar item de recertificação: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrRecertificationItemDecided
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// CountPendingItems conta os itens da campanha que aguardam decisão
+func (r *RecertificationRepository) CountPendingItems(ctx context.Context, tenantID, campaignID uuid.UUID) (int, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.CountPendingItems")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_campaign.id", campaignID.String()),
+	)
+
+	query := `
+		SELECT COUNT(*)
+		FROM recertification_items
+		WHERE tenant_id = $1 AND campaign_id = $2 AND status = 'pending'
+	`
+
+	var pending int
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, tenantID, campaignID).Scan(&pending); err != nil {
+			return fmt.Errorf("erro ao contar itens de recertificação por decidir: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return pending, nil
+}
+
+// ListPendingRevocations recupera, de todos os tenants, os itens revogados cuja atribuição ainda não foi retirada
+func (r *RecertificationRepository) ListPendingRevocations(ctx context.Context, limit int) ([]*model.RecertificationItem, error) {
+	ctx, span := tracer.Start(ctx, "RecertificationRepository.ListPendingRevocations")
+	defer span.End()
+
+	query := `SELECT ` + recertificationItemColumns + `
+		FROM recertification_items
+		WHERE status = 'revoked' AND revoked_at IS NULL
+		ORDER BY decided_at
+		LIMIT $1
+	`
+
+	items, err := r.queryItems(ctx, query, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("recertification_item.count", len(items)))
+	return items, nil
+}
+
+// queryCampaigns executa uma consulta de campanhas
+func (r *RecertificationRepository) queryCampaigns(ctx context.Context, query string, args ...interface{}) ([]*model.RecertificationCampaign, error) {
+	var campaigns []*model.RecertificationCampaign
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar campanhas de recertificação: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			campaign, err := scanRecertificationCampaign(rows)
+			if err != nil {
+				return err
+			}
+			campaigns = append(campaigns, campaign)
+		}
+		return rows.Err()
+	})
+	return campaigns, err
+}
+
+// queryItems executa uma consulta de itens
+func (r *RecertificationRepository) queryItems(ctx context.Context, query string, args ...interface{}) ([]*model.RecertificationItem, error) {
+	var items []*model.RecertificationItem
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar itens de recertificação: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			item, err := scanRecertificationItem(rows)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return rows.Err()
+	})
+	return items, err
+}
+
+// scanRecertificationCampaign lê uma campanha de uma linha
+func scanRecertificationCampaign(row pgx.Row) (*model.RecertificationCampaign, error) {
+	var (
+		campaign         model.RecertificationCampaign
+		roleIDs, userIDs []string
+		status           string
+	)
+	err := row.Scan(
+		&campaign.ID, &campaign.TenantID, &campaign.Name, &campaign.Description, &roleIDs, &userIDs,
+		&campaign.DefaultReviewerID, &campaign.EscalationReviewerID, &status, &campaign.ItemCount,
+		&campaign.EscalateAt, &campaign.Deadline, &campaign.EscalatedAt, &campaign.CreatedBy, &campaign.CreatedAt,
+		&campaign.CompletedAt, &campaign.CancelledBy, &campaign.CancelledAt, &campaign.CancelReason,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler campanha de recertificação: %w", err)
+	}
+	campaign.Status = model.RecertificationCampaignStatus(status)
+
+	if campaign.RoleIDs, err = parseUUIDs(roleIDs); err != nil {
+		return nil, fmt.Errorf("erro ao ler funções da campanha de recertificação: %w", err)
+	}
+	if campaign.UserIDs, err = parseUUIDs(userIDs); err != nil {
+		return nil, fmt.Errorf("erro ao ler população da campanha de recertificação: %w", err)
+	}
+	return &campaign, nil
+}
+
+// scanRecertificationItem lê um item de uma linha
+func scanRecertificationItem(row pgx.Row) (*model.RecertificationItem, error) {
+	var (
+		item   model.RecertificationItem
+		status string
+	)
+	err := row.Scan(
+		&item.ID, &item.TenantID, &item.CampaignID, &item.UserID, &item.RoleID, &item.RoleCode, &item.RoleName,
+		&item.AssignedAt, &item.AssignedBy, &item.AssignmentExpiresAt, &item.ReviewerID, &status,
+		&item.Escalated, &item.EscalatedAt, &item.DecidedBy, &item.DecidedAt, &item.Comment, &item.AutoRevoked,
+		&item.RevokedAt, &item.RevocationError,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler item de recertificação: %w", err)
+	}
+	item.Status = model.RecertificationItemStatus(status)
+	return &item, nil
+}
+
+// parseUUIDs converte os valores lidos de colunas UUID[]; uma lista vazia resulta em nil
+func parseUUIDs(values []string) ([]uuid.UUID, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// RoleEventStore implementa a interface repository.RoleEventStore usando PostgreSQL
+// A tabela role_events é append-only: atualizações e exclusões são bloqueadas por trigger
+type RoleEventStore struct {
+	db *DB
+}
+
+// NewRoleEventStore cria uma nova instância do RoleEventStore
+func NewRoleEventStore(db *DB) *RoleEventStore {
+	return &RoleEventStore{db: db}
+}
+
+// Append acrescenta eventos ao histórico da função numa única transação
+func (s *RoleEventStore) Append(ctx context.Context, events ...*model.RoleHistoryEvent) error {
+	ctx, span := tracer.Start(ctx, "RoleEventStore.Append")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("events.count", len(events)))
+
+	query := `
+		INSERT INTO role_events (
+			id, tenant_id, role_id, version, event_type,
+			payload, actor_id, occurred_at
+		) VALUES (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8
+		)
+	`
+
+	err := s.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		for _, evt := range events {
+			payload, err := json.Marshal(evt.Payload)
+			if err != nil {
+				return fmt.Errorf("erro ao serializar evento da função: %w", err)
+			}
+
+			_, err = tx.Exec(ctx, query,
+				evt.ID, evt.TenantID, evt.RoleID, evt.Version, string(evt.Type),
+				payload, evt.ActorID, evt.OccurredAt,
+			)
+			if err != nil {
+				// A chave (tenant_id, role_id, version) garante a ordem do histórico
+				if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+					return fmt.Errorf("%w: versão %d da função %s", model.ErrRoleHistoryVersionConflict, evt.Version, evt.RoleID)
+				}
+				return fmt.Errorf("erro ao inserir evento da função: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// LastVersion retorna a versão do último evento da função
+func (s *RoleEventStore) LastVersion(ctx context.Context, tenantID, roleID uuid.UUID) (int64, error) {
+	ctx, span := tracer.Start(ctx, "RoleEventStore.LastVersion")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role.id", roleID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `
+		SELECT COALESCE(MAX(version), 0)
+		FROM role_events
+		WHERE tenant_id = $1 AND role_id = $2
+	`
+
+	var version int64
+	err := s.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, tenantID, roleID).Scan(&version); err != nil {
+			return fmt.Errorf("erro ao consultar versão do histórico da função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// LoadEvents recupera os eventos da função posteriores a afterVersion e ocorridos até until
+func (s *RoleEventStore) LoadEvents(ctx context.Context, tenantID, roleID uuid.UUID, afterVersion int64, until time.Time) ([]*model.RoleHistoryEvent, error) {
+	ctx, span := tracer.Start(ctx, "RoleEventStore.LoadEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role.id", roleID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Int64("after_version", afterVersion),
+	)
+
+	query := `
+		SELECT
+			id, tenant_id, role_id, version, event_type,
+			payload, actor_id, occurred_at
+		FROM role_events
+		WHERE tenant_id = $1 AND role_id = $2 AND version > $3 AND occurred_at <= $4
+		ORDER BY version ASC
+	`
+
+	var events []*model.RoleHistoryEvent
+	err := s.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, roleID, afterVersion, until)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar eventos da função: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				evt       model.RoleHistoryEvent
+				eventType string
+				payload   []byte
+			)
+			if err := rows.Scan(
+				&evt.ID, &evt.TenantID, &evt.RoleID, &evt.Version, &eventType,
+				&payload, &evt.ActorID, &evt.OccurredAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler evento da função: %w", err)
+			}
+
+			evt.Type = model.RoleHistoryEventType(eventType)
+			if err := json.Unmarshal(payload, &evt.Payload); err != nil {
+				return fmt.Errorf("erro ao desserializar evento %s da função: %w", evt.ID, err)
+			}
+			events = append(events, &evt)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// SaveSnapshot armazena o estado materializado da função numa versão
+func (s *RoleEventStore) SaveSnapshot(ctx context.Context, snapshot *model.RoleSnapshot) error {
+	ctx, span := tracer.Start(ctx, "RoleEventStore.SaveSnapshot")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role.id", snapshot.RoleID.String()),
+		attribute.String("tenant.id", snapshot.TenantID.String()),
+		attribute.Int64("snapshot.version", snapshot.Version),
+	)
+
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar snapshot da função: %w", err)
+	}
+
+	// Snapshots são derivados do histórico: regravar a mesma versão não altera o estado
+	query := `
+		INSERT INTO role_snapshots (tenant_id, role_id, version, state, taken_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, role_id, version) DO NOTHING
+	`
+
+	err = s.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, snapshot.TenantID, snapshot.RoleID, snapshot.Version, state, snapshot.TakenAt)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir snapshot da função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// LatestSnapshot recupera o snapshot mais recente tirado até o instante indicado
+func (s *RoleEventStore) LatestSnapshot(ctx context.Context, tenantID, roleID uuid.UUID, until time.Time) (*model.RoleSnapshot, error) {
+	ctx, span := tracer.Start(ctx, "RoleEventStore.LatestSnapshot")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role.id", roleID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `
+		SELECT tenant_id, role_id, version, state, taken_at
+		FROM role_snapshots
+		WHERE tenant_id = $1 AND role_id = $2 AND taken_at <= $3
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	var snapshot *model.RoleSnapshot
+	err := s.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var (
+			found model.RoleSnapshot
+			state []byte
+		)
+		err := tx.QueryRow(ctx, query, tenantID, roleID, until).Scan(
+			&found.TenantID, &found.RoleID, &found.Version, &state, &found.TakenAt,
+		)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			return fmt.Errorf("erro ao consultar snapshot da função: %w", err)
+		}
+
+		if err := json.Unmarshal(state, &found.State); err != nil {
+			return fmt.Errorf("erro ao desserializar snapshot da função: %w", err)
+		}
+		snapshot = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return snapshot, nil
+}
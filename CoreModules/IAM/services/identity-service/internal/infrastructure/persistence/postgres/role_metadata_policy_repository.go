@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// RoleMetadataPolicyRepository implementa a interface repository.RoleMetadataPolicyRepository usando PostgreSQL
+type RoleMetadataPolicyRepository struct {
+	db *DB
+}
+
+// NewRoleMetadataPolicyRepository cria uma nova instância do RoleMetadataPolicyRepository
+func NewRoleMetadataPolicyRepository(db *DB) *RoleMetadataPolicyRepository {
+	return &RoleMetadataPolicyRepository{db: db}
+}
+
+// ListPolicyPermissions recupera os códigos das permissões ativas que protegem chaves dos metadados
+func (r *RoleMetadataPolicyRepository) ListPolicyPermissions(ctx context.Context, tenantID uuid.UUID) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "RoleMetadataPolicyRepository.ListPolicyPermissions")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT code
+		FROM permissions
+		WHERE tenant_id = $1 AND starts_with(code, $2)
+		  AND is_active = true AND deleted_at IS NULL
+		ORDER BY code
+	`
+
+	var permissionCodes []string
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, model.RoleMetadataPermissionPrefix)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar permissões dos metadados das funções: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var code string
+			if err := rows.Scan(&code); err != nil {
+				return fmt.Errorf("erro ao ler permissão dos metadados das funções: %w", err)
+			}
+			permissionCodes = append(permissionCodes, code)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return permissionCodes, nil
+}
+
+// ListGrantedPermissions retorna os códigos indicados que o usuário detém diretamente ou via funções
+// As funções expiradas ou removidas não concedem permissões; as funções ascendentes são incluídas
+func (r *RoleMetadataPolicyRepository) ListGrantedPermissions(ctx context.Context, tenantID, userID uuid.UUID, permissionCodes []string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "RoleMetadataPolicyRepository.ListGrantedPermissions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.Int("permissions", len(permissionCodes)),
+	)
+
+	if len(permissionCodes) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		WITH RECURSIVE user_role_tree AS (
+			SELECT ur.role_id AS id
+			FROM user_roles ur
+			JOIN roles r ON r.id = ur.role_id AND r.tenant_id = ur.tenant_id
+			WHERE ur.tenant_id = $1 AND ur.user_id = $2
+			  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+			  AND r.is_active = true AND r.deleted_at IS NULL
+
+			UNION
+
+			SELECT r.id
+			FROM roles r
+			JOIN role_hierarchy rh ON r.id = rh.parent_role_id
+			JOIN user_role_tree urt ON rh.child_role_id = urt.id
+			WHERE rh.tenant_id = $1 AND r.tenant_id = $1
+			  AND r.is_active = true AND r.deleted_at IS NULL
+		)
+		SELECT p.code
+		FROM permissions p
+		WHERE p.tenant_id = $1 AND p.code = ANY($3)
+		  AND p.is_active = true AND p.deleted_at IS NULL
+		  AND (
+			EXISTS (
+				SELECT 1 FROM role_permissions rp
+				JOIN user_role_tree urt ON rp.role_id = urt.id
+				WHERE rp.permission_id = p.id AND rp.tenant_id = $1
+			)
+			OR EXISTS (
+				SELECT 1 FROM user_permissions up
+				WHERE up.permission_id = p.id AND up.tenant_id = $1 AND up.user_id = $2
+				  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+			)
+		  )
+		ORDER BY p.code
+	`
+
+	var granted []string
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID, permissionCodes)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar permissões do usuário: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var code string
+			if err := rows.Scan(&code); err != nil {
+				return fmt.Errorf("erro ao ler permissão do usuário: %w", err)
+			}
+			granted = append(granted, code)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return granted, nil
+}
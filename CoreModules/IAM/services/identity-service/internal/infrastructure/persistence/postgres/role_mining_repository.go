@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de sugestões de função
+const roleSuggestionColumns = `
+	id, tenant_id, fingerprint, permission_ids, permission_codes, user_ids, user_count,
+	covered_assignments, cohesion, status, role_id, reviewed_by, reviewed_at, generated_at
+`
+
+// Permissões diretas em vigor, de permissões ativas atribuídas a usuários ativos
+const directAssignmentsFilter = `
+	FROM user_permissions up
+	JOIN permissions p ON p.id = up.permission_id AND p.tenant_id = up.tenant_id
+	JOIN users u ON u.id = up.user_id AND u.tenant_id = up.tenant_id
+	WHERE p.is_active = true AND p.deleted_at IS NULL
+	  AND u.status = 'active' AND u.deleted_at IS NULL
+	  AND (up.expires_at IS NULL OR up.expires_at > NOW())
+`
+
+// RoleMiningRepository implementa a interface repository.RoleMiningRepository usando PostgreSQL
+type RoleMiningRepository struct {
+	db *DB
+}
+
+// NewRoleMiningRepository cria uma nova instância do RoleMiningRepository
+func NewRoleMiningRepository(db *DB) *RoleMiningRepository {
+	return &RoleMiningRepository{db: db}
+}
+
+// ListTenantsWithDirectPermissions recupera os tenants com permissões atribuídas diretamente a usuários
+func (r *RoleMiningRepository) ListTenantsWithDirectPermissions(ctx context.Context) ([]uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.ListTenantsWithDirectPermissions")
+	defer span.End()
+
+	query := `SELECT DISTINCT up.tenant_id ` + directAssignmentsFilter + ` ORDER BY up.tenant_id`
+
+	var tenants []uuid.UUID
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar tenants com permissões diretas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var tenantID uuid.UUID
+			if err := rows.Scan(&tenantID); err != nil {
+				return fmt.Errorf("erro ao ler tenant com permissões diretas: %w", err)
+			}
+			tenants = append(tenants, tenantID)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// ListDirectAssignments recupera as permissões diretas em vigor dos usuários ativos do tenant
+func (r *RoleMiningRepository) ListDirectAssignments(ctx context.Context, tenantID uuid.UUID) ([]*model.DirectPermissionAssignment, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.ListDirectAssignments")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT up.user_id, up.permission_id, p.code ` + directAssignmentsFilter + `
+		  AND up.tenant_id = $1
+		ORDER BY up.user_id, p.code
+	`
+
+	var assignments []*model.DirectPermissionAssignment
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar permissões diretas: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var assignment model.DirectPermissionAssignment
+			if err := rows.Scan(&assignment.UserID, &assignment.PermissionID, &assignment.PermissionCode); err != nil {
+				return fmt.Errorf("erro ao ler permissão direta: %w", err)
+			}
+			assignments = append(assignments, &assignment)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("assignments", len(assignments)))
+	return assignments, nil
+}
+
+// ReplacePendingSuggestions substitui as sugestões pendentes do tenant, mantendo as já revistas
+func (r *RoleMiningRepository) ReplacePendingSuggestions(ctx context.Context, tenantID uuid.UUID, suggestions []*model.RoleSuggestion) error {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.ReplacePendingSuggestions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Int("suggestions", len(suggestions)),
+	)
+
+	deleteQuery := `DELETE FROM role_suggestions WHERE tenant_id = $1 AND status = 'pending'`
+	insertQuery := `
+		INSERT INTO role_suggestions (
+			id, tenant_id, fingerprint, permission_ids, permission_codes, user_ids, user_count,
+			covered_assignments, cohesion, status, generated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, deleteQuery, tenantID); err != nil {
+			return fmt.Errorf("erro ao remover sugestões de função pendentes: %w", err)
+		}
+		for _, suggestion := range suggestions {
+			_, err := tx.Exec(ctx, insertQuery,
+				suggestion.ID, tenantID, suggestion.Fingerprint, suggestion.PermissionIDs, suggestion.PermissionCodes,
+				suggestion.UserIDs, suggestion.UserCount, suggestion.CoveredAssignments, suggestion.Cohesion,
+				suggestion.Status, suggestion.GeneratedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao inserir sugestão de função: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListSuggestions recupera as sugestões do tenant no estado indicado (todas quando vazio)
+func (r *RoleMiningRepository) ListSuggestions(ctx context.Context, tenantID uuid.UUID, status string) ([]*model.RoleSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.ListSuggestions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("role_suggestion.status", status),
+	)
+
+	query := `SELECT ` + roleSuggestionColumns + `
+		FROM role_suggestions
+		WHERE tenant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY covered_assignments DESC, fingerprint ASC
+	`
+
+	var suggestions []*model.RoleSuggestion
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, status)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar sugestões de função: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			suggestion, err := scanRoleSuggestion(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler sugestão de função: %w", err)
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// GetSuggestion recupera uma sugestão do tenant
+func (r *RoleMiningRepository) GetSuggestion(ctx context.Context, tenantID, suggestionID uuid.UUID) (*model.RoleSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.GetSuggestion")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role_suggestion.id", suggestionID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + roleSuggestionColumns + `
+		FROM role_suggestions
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var suggestion *model.RoleSuggestion
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		suggestion, err = scanRoleSuggestion(tx.QueryRow(ctx, query, tenantID, suggestionID))
+		if err == pgx.ErrNoRows {
+			return model.ErrRoleSuggestionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar sugestão de função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return suggestion, nil
+}
+
+// SaveReview grava a revisão de uma sugestão ainda pendente
+func (r *RoleMiningRepository) SaveReview(ctx context.Context, suggestion *model.RoleSuggestion) error {
+	ctx, span := tracer.Start(ctx, "RoleMiningRepository.SaveReview")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role_suggestion.id", suggestion.ID.String()),
+		attribute.String("tenant.id", suggestion.TenantID.String()),
+		attribute.String("role_suggestion.status", suggestion.Status),
+	)
+
+	// A condição sobre o estado impede que duas revisões concorrentes sejam gravadas
+	query := `
+		UPDATE role_suggestions
+		SET status = $3, role_id = $4, reviewed_by = $5, reviewed_at = $6
+		WHERE tenant_id = $1 AND id = $2 AND status = 'pending'
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			suggestion.TenantID, suggestion.ID, suggestion.Status, suggestion.RoleID, suggestion.ReviewedBy,
+			suggestion.ReviewedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar sugestão de função: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrRoleSuggestionNotPending
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanRoleSuggestion lê uma sugestão a partir de uma linha com as colunas de roleSuggestionColumns
+func scanRoleSuggestion(row pgx.Row) (*model.RoleSuggestion, error) {
+	var suggestion model.RoleSuggestion
+	err := row.Scan(
+		&suggestion.ID, &suggestion.TenantID, &suggestion.Fingerprint, &suggestion.PermissionIDs,
+		&suggestion.PermissionCodes, &suggestion.UserIDs, &suggestion.UserCount, &suggestion.CoveredAssignments,
+		&suggestion.Cohesion, &suggestion.Status, &suggestion.RoleID, &suggestion.ReviewedBy, &suggestion.ReviewedAt,
+		&suggestion.GeneratedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &suggestion, nil
+}
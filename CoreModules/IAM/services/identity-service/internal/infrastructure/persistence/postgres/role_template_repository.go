@@ -0,0 +1,423 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de modelos de função
+const (
+	roleTemplateColumns = `
+	id, code, name, COALESCE(description, ''), COALESCE(market, ''), version,
+	permission_codes, metadata, is_active, created_by, created_at, updated_at
+`
+	roleTemplateInstanceColumns = `
+	id, template_id, template_version, tenant_id, role_id, permission_mapping,
+	unmapped_codes, instantiated_by, instantiated_at
+`
+)
+
+// RoleTemplateRepository implementa a interface repository.RoleTemplateRepository usando PostgreSQL
+type RoleTemplateRepository struct {
+	db *DB
+}
+
+// NewRoleTemplateRepository cria uma nova instância do RoleTemplateRepository
+func NewRoleTemplateRepository(db *DB) *RoleTemplateRepository {
+	return &RoleTemplateRepository{db: db}
+}
+
+// CreateTemplate grava um novo modelo no catálogo
+func (r *RoleTemplateRepository) CreateTemplate(ctx context.Context, template *model.RoleTemplate) error {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.CreateTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role_template.id", template.ID.String()),
+		attribute.String("role_template.code", template.Code),
+	)
+
+	metadata, err := json.Marshal(template.Metadata)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar metadados do modelo de função: %w", err)
+	}
+
+	query := `
+		INSERT INTO role_templates (
+			id, code, name, description, market, version, permission_codes,
+			metadata, is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			template.ID, template.Code, template.Name, template.Description, template.Market, template.Version,
+			template.PermissionCodes, metadata, template.IsActive, template.CreatedBy, template.CreatedAt, template.UpdatedAt,
+		)
+		if err != nil {
+			// O índice único admite um único modelo por código e mercado
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrRoleTemplateCodeAlreadyExists
+			}
+			return fmt.Errorf("erro ao inserir modelo de função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetTemplate recupera um modelo pelo ID
+func (r *RoleTemplateRepository) GetTemplate(ctx context.Context, templateID uuid.UUID) (*model.RoleTemplate, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.GetTemplate")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("role_template.id", templateID.String()))
+
+	query := `SELECT ` + roleTemplateColumns + ` FROM role_templates WHERE id = $1`
+
+	var template *model.RoleTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		template, err = scanRoleTemplate(tx.QueryRow(ctx, query, templateID))
+		if err == pgx.ErrNoRows {
+			return model.ErrRoleTemplateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelo de função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ListTemplates recupera os modelos que satisfazem o filtro
+func (r *RoleTemplateRepository) ListTemplates(ctx context.Context, filter model.RoleTemplateFilter) ([]*model.RoleTemplate, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.ListTemplates")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("filter.market", filter.Market),
+		attribute.Bool("filter.active_only", filter.ActiveOnly),
+	)
+
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.Market != "" {
+		args = append(args, strings.ToLower(filter.Market))
+		conditions = append(conditions, fmt.Sprintf("(market IS NULL OR market = $%d)", len(args)))
+	}
+	if filter.ActiveOnly {
+		conditions = append(conditions, "is_active = true")
+	}
+
+	query := `SELECT ` + roleTemplateColumns + `
+		FROM role_templates
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY code ASC, market ASC NULLS FIRST
+	`
+
+	var templates []*model.RoleTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelos de função: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			template, err := scanRoleTemplate(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler modelo de função: %w", err)
+			}
+			templates = append(templates, template)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// UpdateTemplate grava as alterações de um modelo
+func (r *RoleTemplateRepository) UpdateTemplate(ctx context.Context, template *model.RoleTemplate, expectedVersion int) error {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.UpdateTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role_template.id", template.ID.String()),
+		attribute.Int("role_template.version", template.Version),
+	)
+
+	// A condição sobre a versão esperada impede que revisões concorrentes se sobreponham
+	query := `
+		UPDATE role_templates
+		SET name = $2, description = NULLIF($3, ''), version = $4, permission_codes = $5,
+			is_active = $6, updated_at = $7
+		WHERE id = $1 AND version = $8
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			template.ID, template.Name, template.Description, template.Version, template.PermissionCodes,
+			template.IsActive, template.UpdatedAt, expectedVersion,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar modelo de função: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: modelo %s já não está na versão %d", model.ErrRoleTemplateConflict, template.ID, expectedVersion)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ResolvePermissions associa os códigos às permissões ativas do tenant
+func (r *RoleTemplateRepository) ResolvePermissions(ctx context.Context, tenantID uuid.UUID, permissionCodes []string) (map[string]uuid.UUID, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.ResolvePermissions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Int("codes", len(permissionCodes)),
+	)
+
+	query := `
+		SELECT code, id FROM permissions
+		WHERE tenant_id = $1 AND code = ANY($2) AND is_active = true AND deleted_at IS NULL
+	`
+
+	resolved := make(map[string]uuid.UUID, len(permissionCodes))
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, permissionCodes)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar permissões por código: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				code string
+				id   uuid.UUID
+			)
+			if err := rows.Scan(&code, &id); err != nil {
+				return fmt.Errorf("erro ao ler permissão: %w", err)
+			}
+			resolved[code] = id
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// CreateInstance grava a ligação entre a função do tenant e o modelo
+func (r *RoleTemplateRepository) CreateInstance(ctx context.Context, instance *model.RoleTemplateInstance) error {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.CreateInstance")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role_template.id", instance.TemplateID.String()),
+		attribute.String("role.id", instance.RoleID.String()),
+		attribute.String("tenant.id", instance.TenantID.String()),
+	)
+
+	mapping, err := json.Marshal(instance.PermissionMapping)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar mapeamento de permissões: %w", err)
+	}
+
+	query := `
+		INSERT INTO role_template_instances (
+			id, template_id, template_version, tenant_id, role_id, permission_mapping,
+			unmapped_codes, instantiated_by, instantiated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			instance.ID, instance.TemplateID, instance.TemplateVersion, instance.TenantID, instance.RoleID, mapping,
+			instance.UnmappedCodes, instance.InstantiatedBy, instance.InstantiatedAt,
+		)
+		if err != nil {
+			// O índice único admite uma única instância de cada modelo por tenant
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrRoleTemplateAlreadyInstantiated
+			}
+			return fmt.Errorf("erro ao inserir instância do modelo de função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetInstanceByRole recupera a instância de uma função
+func (r *RoleTemplateRepository) GetInstanceByRole(ctx context.Context, tenantID, roleID uuid.UUID) (*model.RoleTemplateInstance, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.GetInstanceByRole")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("role.id", roleID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + roleTemplateInstanceColumns + `
+		FROM role_template_instances
+		WHERE tenant_id = $1 AND role_id = $2
+	`
+
+	var instance *model.RoleTemplateInstance
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		instance, err = scanRoleTemplateInstance(tx.QueryRow(ctx, query, tenantID, roleID))
+		if err == pgx.ErrNoRows {
+			return model.ErrRoleTemplateInstanceNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar instância do modelo de função: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return instance, nil
+}
+
+// ListInstances recupera as instâncias do tenant
+func (r *RoleTemplateRepository) ListInstances(ctx context.Context, tenantID uuid.UUID) ([]*model.RoleTemplateInstance, error) {
+	ctx, span := tracer.Start(ctx, "RoleTemplateRepository.ListInstances")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + roleTemplateInstanceColumns + `
+		FROM role_template_instances
+		WHERE tenant_id = $1
+		ORDER BY instantiated_at ASC
+	`
+
+	var instances []*model.RoleTemplateInstance
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar instâncias de modelos de função: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			instance, err := scanRoleTemplateInstance(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler instância do modelo de função: %w", err)
+			}
+			instances = append(instances, instance)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return instances, nil
+}
+
+// scanRoleTemplate lê as colunas de roleTemplateColumns
+func scanRoleTemplate(row pgx.Row) (*model.RoleTemplate, error) {
+	var (
+		template model.RoleTemplate
+		metadata []byte
+	)
+	err := row.Scan(
+		&template.ID, &template.Code, &template.Name, &template.Description, &template.Market, &template.Version,
+		&template.PermissionCodes, &metadata, &template.IsActive, &template.CreatedBy, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &template.Metadata); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar metadados do modelo de função: %w", err)
+		}
+	}
+	return &template, nil
+}
+
+// scanRoleTemplateInstance lê as colunas de roleTemplateInstanceColumns
+func scanRoleTemplateInstance(row pgx.Row) (*model.RoleTemplateInstance, error) {
+	var (
+		instance model.RoleTemplateInstance
+		mapping  []byte
+	)
+	err := row.Scan(
+		&instance.ID, &instance.TemplateID, &instance.TemplateVersion, &instance.TenantID, &instance.RoleID, &mapping,
+		&instance.UnmappedCodes, &instance.InstantiatedBy, &instance.InstantiatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mapping) > 0 {
+		if err := json.Unmarshal(mapping, &instance.PermissionMapping); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar mapeamento de permissões: %w", err)
+		}
+	}
+	return &instance, nil
+}
@@ -0,0 +1,539 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório da federação SAML
+const (
+	samlProviderColumns = `
+	id, tenant_id, name, entity_id, sso_url, signing_certificates, COALESCE(name_id_format, ''),
+	attribute_mapping, role_mapping_rules, jit_provisioning, is_active, created_by, created_at, updated_at
+`
+	federatedIdentityColumns = `
+	id, tenant_id, provider_id, subject, user_id, granted_role_ids::TEXT[], created_at, last_login_at
+`
+)
+
+// SAMLFederationRepository implementa a interface repository.SAMLFederationRepository usando PostgreSQL
+type SAMLFederationRepository struct {
+	db *DB
+}
+
+// NewSAMLFederationRepository cria uma nova instância do SAMLFederationRepository
+func NewSAMLFederationRepository(db *DB) *SAMLFederationRepository {
+	return &SAMLFederationRepository{db: db}
+}
+
+// CreateProvider grava um novo fornecedor de identidade
+func (r *SAMLFederationRepository) CreateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.CreateProvider")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("saml_provider.id", provider.ID.String()),
+		attribute.String("saml_provider.entity_id", provider.EntityID),
+		attribute.String("tenant.id", provider.TenantID.String()),
+	)
+
+	mapping, rules, err := marshalSAMLProviderMappings(provider)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO saml_identity_providers (
+			id, tenant_id, name, entity_id, sso_url, signing_certificates, name_id_format,
+			attribute_mapping, role_mapping_rules, jit_provisioning, is_active, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			provider.ID, provider.TenantID, provider.Name, provider.EntityID, provider.SSOURL, provider.SigningCertificates,
+			provider.NameIDFormat, mapping, rules, provider.JITProvisioning, provider.IsActive, provider.CreatedBy,
+			provider.CreatedAt, provider.UpdatedAt,
+		)
+		if err != nil {
+			// A restrição única admite um único IdP por entity ID em cada tenant
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrSAMLProviderAlreadyExists
+			}
+			return fmt.Errorf("erro ao inserir fornecedor de identidade SAML: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetProvider recupera um fornecedor de identidade pelo ID
+func (r *SAMLFederationRepository) GetProvider(ctx context.Context, providerID uuid.UUID) (*model.SAMLIdentityProvider, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.GetProvider")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("saml_provider.id", providerID.String()))
+
+	query := `SELECT ` + samlProviderColumns + ` FROM saml_identity_providers WHERE id = $1`
+
+	var provider *model.SAMLIdentityProvider
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		provider, err = scanSAMLProvider(tx.QueryRow(ctx, query, providerID))
+		if err == pgx.ErrNoRows {
+			return model.ErrSAMLProviderNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar fornecedor de identidade SAML: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// ListProviders recupera os fornecedores de identidade do tenant
+func (r *SAMLFederationRepository) ListProviders(ctx context.Context, tenantID uuid.UUID) ([]*model.SAMLIdentityProvider, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.ListProviders")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + samlProviderColumns + `
+		FROM saml_identity_providers
+		WHERE tenant_id = $1
+		ORDER BY name ASC
+	`
+
+	var providers []*model.SAMLIdentityProvider
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar fornecedores de identidade SAML: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			provider, err := scanSAMLProvider(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler fornecedor de identidade SAML: %w", err)
+			}
+			providers = append(providers, provider)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+// UpdateProvider grava as alterações de um fornecedor de identidade
+func (r *SAMLFederationRepository) UpdateProvider(ctx context.Context, provider *model.SAMLIdentityProvider) error {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.UpdateProvider")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("saml_provider.id", provider.ID.String()),
+		attribute.String("tenant.id", provider.TenantID.String()),
+	)
+
+	mapping, rules, err := marshalSAMLProviderMappings(provider)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE saml_identity_providers
+		SET name = $3, entity_id = $4, sso_url = $5, signing_certificates = $6, name_id_format = NULLIF($7, ''),
+			attribute_mapping = $8, role_mapping_rules = $9, jit_provisioning = $10, is_active = $11, updated_at = $12
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			provider.ID, provider.TenantID, provider.Name, provider.EntityID, provider.SSOURL, provider.SigningCertificates,
+			provider.NameIDFormat, mapping, rules, provider.JITProvisioning, provider.IsActive, provider.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrSAMLProviderAlreadyExists
+			}
+			return fmt.Errorf("erro ao atualizar fornecedor de identidade SAML: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrSAMLProviderNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// SaveAuthnRequest grava um pedido de autenticação emitido
+func (r *SAMLFederationRepository) SaveAuthnRequest(ctx context.Context, request *model.SAMLAuthnRequest) error {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.SaveAuthnRequest")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("saml_provider.id", request.ProviderID.String()))
+
+	query := `
+		INSERT INTO saml_authn_requests (id, tenant_id, provider_id, relay_state, return_to, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			request.ID, request.TenantID, request.ProviderID, request.RelayState, request.ReturnTo,
+			request.CreatedAt, request.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir pedido de autenticação SAML: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeAuthnRequest marca como consumido o pedido do fornecedor com o relay state indicado
+func (r *SAMLFederationRepository) ConsumeAuthnRequest(ctx context.Context, providerID uuid.UUID, relayState string, now time.Time) (*model.SAMLAuthnRequest, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.ConsumeAuthnRequest")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("saml_provider.id", providerID.String()))
+
+	// A atualização condicional garante que cada pedido é consumido uma única vez
+	query := `
+		UPDATE saml_authn_requests
+		SET consumed_at = $3
+		WHERE provider_id = $1 AND relay_state = $2 AND consumed_at IS NULL AND expires_at > $3
+		RETURNING id, tenant_id, provider_id, relay_state, COALESCE(return_to, ''), created_at, expires_at, consumed_at
+	`
+
+	var request model.SAMLAuthnRequest
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, providerID, relayState, now).Scan(
+			&request.ID, &request.TenantID, &request.ProviderID, &request.RelayState, &request.ReturnTo,
+			&request.CreatedAt, &request.ExpiresAt, &request.ConsumedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return model.ErrSAMLRequestNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consumir pedido de autenticação SAML: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// GetFederatedIdentity recupera a identidade federada do NameID emitido pelo fornecedor
+func (r *SAMLFederationRepository) GetFederatedIdentity(ctx context.Context, providerID uuid.UUID, subject string) (*model.FederatedIdentity, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.GetFederatedIdentity")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("saml_provider.id", providerID.String()))
+
+	query := `SELECT ` + federatedIdentityColumns + `
+		FROM federated_identities
+		WHERE provider_id = $1 AND subject = $2
+	`
+
+	var identity *model.FederatedIdentity
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		identity, err = scanFederatedIdentity(tx.QueryRow(ctx, query, providerID, subject))
+		if err == pgx.ErrNoRows {
+			return model.ErrFederatedIdentityNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar identidade federada: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// GetUser recupera o usuário ligado a uma identidade federada
+func (r *SAMLFederationRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.GetUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var user *model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		user, err = scanLifecycleUser(tx.QueryRow(ctx, query, tenantID, userID))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuário: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ProvisionUser cria o usuário, as suas credenciais federadas e a identidade federada numa única transação
+func (r *SAMLFederationRepository) ProvisionUser(ctx context.Context, user *model.User, identity *model.FederatedIdentity) error {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.ProvisionUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", user.TenantID.String()),
+		attribute.String("user.id", user.ID.String()),
+		attribute.String("saml_provider.id", identity.ProviderID.String()),
+	)
+
+	metadata, err := json.Marshal(user.Metadata)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar metadados do usuário: %w", err)
+	}
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO users (
+				id, tenant_id, username, email, email_verified, first_name, last_name, display_name,
+				status, status_changed_at, metadata, login_count, last_login_at, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15)
+		`,
+			user.ID, user.TenantID, user.Username, user.Email, user.EmailVerified, user.FirstName, user.LastName,
+			user.DisplayName, string(user.Status), user.StatusChangedAt, metadata, user.LoginCount, user.LastLoginAt,
+			user.CreatedAt, user.UpdatedAt,
+		)
+		if err != nil {
+			// As restrições únicas de email e nome de usuário impedem a ligação automática a contas locais
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrSAMLAccountConflict
+			}
+			return fmt.Errorf("erro ao inserir usuário federado: %w", err)
+		}
+
+		if credentials := user.Credentials; credentials != nil {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO user_credentials (
+					id, user_id, provider, provider_user_id, failed_attempts, created_at, updated_at
+				) VALUES ($1, $2, $3, $4, 0, $5, $6)
+			`,
+				credentials.ID, credentials.UserID, string(credentials.Provider), credentials.ProviderUserID,
+				credentials.CreatedAt, credentials.UpdatedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("erro ao inserir credenciais federadas: %w", err)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO federated_identities (
+				id, tenant_id, provider_id, subject, user_id, granted_role_ids, created_at, last_login_at
+			) VALUES ($1, $2, $3, $4, $5, $6::UUID[], $7, $8)
+		`,
+			identity.ID, identity.TenantID, identity.ProviderID, identity.Subject, identity.UserID,
+			uuidStrings(identity.GrantedRoleIDs), identity.CreatedAt, identity.LastLoginAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrSAMLAccountConflict
+			}
+			return fmt.Errorf("erro ao inserir identidade federada: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordLogin grava as funções atribuídas pela federação e o instante do login
+// O login também conta para o usuário, para que não seja considerado inativo
+func (r *SAMLFederationRepository) RecordLogin(ctx context.Context, identity *model.FederatedIdentity) error {
+	ctx, span := tracer.Start(ctx, "SAMLFederationRepository.RecordLogin")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", identity.TenantID.String()),
+		attribute.String("user.id", identity.UserID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE federated_identities
+			SET granted_role_ids = $2::UUID[], last_login_at = $3
+			WHERE id = $1
+		`, identity.ID, uuidStrings(identity.GrantedRoleIDs), identity.LastLoginAt)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar identidade federada: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE users
+			SET login_count = login_count + 1, last_login_at = $3
+			WHERE tenant_id = $1 AND id = $2
+		`, identity.TenantID, identity.UserID, identity.LastLoginAt)
+		if err != nil {
+			return fmt.Errorf("erro ao registar login do usuário federado: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// marshalSAMLProviderMappings serializa o mapeamento de atributos e as regras de funções
+func marshalSAMLProviderMappings(provider *model.SAMLIdentityProvider) ([]byte, []byte, error) {
+	mapping, err := json.Marshal(provider.AttributeMapping)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar mapeamento de atributos SAML: %w", err)
+	}
+	rules := provider.RoleMappingRules
+	if rules == nil {
+		rules = []model.SAMLRoleMappingRule{}
+	}
+	encodedRules, err := json.Marshal(rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar regras de mapeamento de funções: %w", err)
+	}
+	return mapping, encodedRules, nil
+}
+
+// scanSAMLProvider lê as colunas de samlProviderColumns
+func scanSAMLProvider(row pgx.Row) (*model.SAMLIdentityProvider, error) {
+	var (
+		provider model.SAMLIdentityProvider
+		mapping  []byte
+		rules    []byte
+	)
+	err := row.Scan(
+		&provider.ID, &provider.TenantID, &provider.Name, &provider.EntityID, &provider.SSOURL, &provider.SigningCertificates,
+		&provider.NameIDFormat, &mapping, &rules, &provider.JITProvisioning, &provider.IsActive, &provider.CreatedBy,
+		&provider.CreatedAt, &provider.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(mapping, &provider.AttributeMapping); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar mapeamento de atributos SAML: %w", err)
+	}
+	if err := json.Unmarshal(rules, &provider.RoleMappingRules); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar regras de mapeamento de funções: %w", err)
+	}
+	return &provider, nil
+}
+
+// scanFederatedIdentity lê as colunas de federatedIdentityColumns
+func scanFederatedIdentity(row pgx.Row) (*model.FederatedIdentity, error) {
+	var (
+		identity model.FederatedIdentity
+		roleIDs  []string
+	)
+	err := row.Scan(
+		&identity.ID, &identity.TenantID, &identity.ProviderID, &identity.Subject, &identity.UserID, &roleIDs,
+		&identity.CreatedAt, &identity.LastLoginAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	identity.GrantedRoleIDs = make([]uuid.UUID, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		roleID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler função atribuída pela federação: %w", err)
+		}
+		identity.GrantedRoleIDs = append(identity.GrantedRoleIDs, roleID)
+	}
+	return &identity, nil
+}
+
+// uuidStrings converte os IDs para texto, para gravação em colunas UUID[]
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
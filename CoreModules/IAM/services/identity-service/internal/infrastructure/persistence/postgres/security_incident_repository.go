@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de incidentes de segurança
+const securityIncidentColumns = `
+	id, tenant_id, rule, severity, status, actor_id, summary, evidence,
+	detected_at, updated_at, triaged_by, COALESCE(resolution_note, ''), closed_at
+`
+
+// Número máximo de incidentes devolvidos quando o filtro não indica limite
+const defaultSecurityIncidentListLimit = 100
+
+// SecurityIncidentRepository implementa a interface repository.SecurityIncidentRepository usando PostgreSQL
+type SecurityIncidentRepository struct {
+	db *DB
+}
+
+// NewSecurityIncidentRepository cria uma nova instância do SecurityIncidentRepository
+func NewSecurityIncidentRepository(db *DB) *SecurityIncidentRepository {
+	return &SecurityIncidentRepository{db: db}
+}
+
+// Create grava um novo incidente
+func (r *SecurityIncidentRepository) Create(ctx context.Context, incident *model.SecurityIncident) error {
+	ctx, span := tracer.Start(ctx, "SecurityIncidentRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("security_incident.id", incident.ID.String()),
+		attribute.String("security_incident.rule", string(incident.Rule)),
+		attribute.String("tenant.id", incident.TenantID.String()),
+	)
+
+	evidence, err := json.Marshal(incident.Evidence)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evidências do incidente: %w", err)
+	}
+
+	query := `
+		INSERT INTO security_incidents (
+			id, tenant_id, rule, severity, status, actor_id, summary, evidence,
+			detected_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			incident.ID, incident.TenantID, string(incident.Rule), string(incident.Severity), string(incident.Status),
+			incident.ActorID, incident.Summary, evidence, incident.DetectedAt, incident.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir incidente de segurança: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Get recupera um incidente pelo ID
+func (r *SecurityIncidentRepository) Get(ctx context.Context, tenantID, incidentID uuid.UUID) (*model.SecurityIncident, error) {
+	ctx, span := tracer.Start(ctx, "SecurityIncidentRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("security_incident.id", incidentID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + securityIncidentColumns + `
+		FROM security_incidents
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var incident *model.SecurityIncident
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		incident, err = scanSecurityIncident(tx.QueryRow(ctx, query, tenantID, incidentID))
+		if err == pgx.ErrNoRows {
+			return model.ErrSecurityIncidentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar incidente de segurança: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return incident, nil
+}
+
+// List recupera os incidentes do tenant que satisfazem o filtro
+func (r *SecurityIncidentRepository) List(ctx context.Context, tenantID uuid.UUID, filter model.SecurityIncidentFilter) ([]*model.SecurityIncident, error) {
+	ctx, span := tracer.Start(ctx, "SecurityIncidentRepository.List")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(filter.Status)),
+		attribute.String("filter.severity", string(filter.Severity)),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", string(filter.Status))
+	}
+	if filter.Severity != "" {
+		addCondition("severity = $%d", string(filter.Severity))
+	}
+	if filter.Rule != "" {
+		addCondition("rule = $%d", string(filter.Rule))
+	}
+	if filter.Since != nil {
+		addCondition("detected_at >= $%d", *filter.Since)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSecurityIncidentListLimit
+	}
+	args = append(args, limit)
+
+	query := `SELECT ` + securityIncidentColumns + `
+		FROM security_incidents
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY detected_at DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	var incidents []*model.SecurityIncident
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar incidentes de segurança: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			incident, err := scanSecurityIncident(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler incidente de segurança: %w", err)
+			}
+			incidents = append(incidents, incident)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// SaveStatus grava o estado e a triagem do incidente
+func (r *SecurityIncidentRepository) SaveStatus(ctx context.Context, incident *model.SecurityIncident, expected model.SecurityIncidentStatus) error {
+	ctx, span := tracer.Start(ctx, "SecurityIncidentRepository.SaveStatus")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("security_incident.id", incident.ID.String()),
+		attribute.String("tenant.id", incident.TenantID.String()),
+		attribute.String("security_incident.status", string(incident.Status)),
+	)
+
+	// A condição sobre o estado esperado impede triagens concorrentes sobre o mesmo incidente
+	query := `
+		UPDATE security_incidents
+		SET status = $3, triaged_by = $4, resolution_note = NULLIF($5, ''),
+			updated_at = $6, closed_at = $7
+		WHERE tenant_id = $1 AND id = $2 AND status = $8
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			incident.TenantID, incident.ID, string(incident.Status), incident.TriagedBy, incident.ResolutionNote,
+			incident.UpdatedAt, incident.ClosedAt, string(expected),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar incidente de segurança: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: incidente %s não está em %s", model.ErrSecurityIncidentConflict, incident.ID, expected)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanSecurityIncident lê um incidente a partir de uma linha com as colunas de securityIncidentColumns
+func scanSecurityIncident(row pgx.Row) (*model.SecurityIncident, error) {
+	var (
+		incident               model.SecurityIncident
+		rule, severity, status string
+		evidence               []byte
+	)
+	err := row.Scan(
+		&incident.ID, &incident.TenantID, &rule, &severity, &status, &incident.ActorID, &incident.Summary, &evidence,
+		&incident.DetectedAt, &incident.UpdatedAt, &incident.TriagedBy, &incident.ResolutionNote, &incident.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(evidence, &incident.Evidence); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar evidências do incidente: %w", err)
+	}
+	incident.Rule = model.SecurityIncidentRule(rule)
+	incident.Severity = model.SecurityIncidentSeverity(severity)
+	incident.Status = model.SecurityIncidentStatus(status)
+	return &incident, nil
+}
@@ -0,0 +1,646 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório das contas de serviço
+// As funções atribuídas são agregadas numa única coluna
+const serviceAccountColumns = `
+	a.id, a.tenant_id, a.name, COALESCE(a.description, ''), a.status,
+	COALESCE(a.k8s_cluster, ''), COALESCE(a.k8s_namespace, ''), COALESCE(a.k8s_service_account, ''),
+	COALESCE((SELECT array_agg(r.role_id ORDER BY r.granted_at) FROM service_account_roles r WHERE r.service_account_id = a.id), '{}'),
+	a.last_used_at,
+	COALESCE(a.created_by, '00000000-0000-0000-0000-000000000000'::UUID),
+	COALESCE(a.updated_by, '00000000-0000-0000-0000-000000000000'::UUID), a.created_at, a.updated_at
+`
+
+const serviceAccountCredentialColumns = `
+	id, tenant_id, service_account_id, type, COALESCE(name, ''), COALESCE(key_prefix, ''), COALESCE(client_id, ''),
+	secret_hash, COALESCE(certificate_fingerprint, ''), COALESCE(certificate_subject, ''), expires_at, last_used_at,
+	COALESCE(created_by, '00000000-0000-0000-0000-000000000000'::UUID), created_at, revoked_by, revoked_at
+`
+
+// ServiceAccountRepository implementa a interface repository.ServiceAccountRepository usando PostgreSQL
+type ServiceAccountRepository struct {
+	db *DB
+}
+
+// NewServiceAccountRepository cria uma nova instância do ServiceAccountRepository
+func NewServiceAccountRepository(db *DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// ListServiceAccounts recupera as contas de serviço do tenant, ordenadas pelo nome
+func (r *ServiceAccountRepository) ListServiceAccounts(ctx context.Context, tenantID uuid.UUID, filter model.ServiceAccountFilter) ([]*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.ListServiceAccounts")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + serviceAccountColumns + `
+		FROM service_accounts a
+		WHERE a.tenant_id = $1 AND ($2 = '' OR a.status = $2)
+		ORDER BY a.name
+	`
+
+	var accounts []*model.ServiceAccount
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, string(filter.Status))
+		if err != nil {
+			return fmt.Errorf("erro ao consultar contas de serviço: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			account, err := scanServiceAccount(rows)
+			if err != nil {
+				return err
+			}
+			accounts = append(accounts, account)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// GetServiceAccount recupera uma conta de serviço do tenant, com as funções atribuídas
+func (r *ServiceAccountRepository) GetServiceAccount(ctx context.Context, tenantID, accountID uuid.UUID) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.GetServiceAccount")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+	)
+
+	query := `SELECT ` + serviceAccountColumns + `
+		FROM service_accounts a
+		WHERE a.tenant_id = $1 AND a.id = $2
+	`
+
+	var account *model.ServiceAccount
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanServiceAccount(tx.QueryRow(ctx, query, tenantID, accountID))
+		if err == pgx.ErrNoRows {
+			return model.ErrServiceAccountNotFound
+		}
+		if err != nil {
+			return err
+		}
+		account = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// FindByWorkloadIdentity recupera a conta de serviço ligada à conta de serviço Kubernetes
+func (r *ServiceAccountRepository) FindByWorkloadIdentity(ctx context.Context, binding model.WorkloadIdentityBinding) (*model.ServiceAccount, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.FindByWorkloadIdentity")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("kubernetes.cluster", binding.Cluster),
+		attribute.String("kubernetes.namespace", binding.Namespace),
+	)
+
+	query := `SELECT ` + serviceAccountColumns + `
+		FROM service_accounts a
+		WHERE a.k8s_cluster = $1 AND a.k8s_namespace = $2 AND a.k8s_service_account = $3
+	`
+
+	var account *model.ServiceAccount
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanServiceAccount(tx.QueryRow(ctx, query, binding.Cluster, binding.Namespace, binding.ServiceAccount))
+		if err == pgx.ErrNoRows {
+			return model.ErrServiceAccountNotFound
+		}
+		if err != nil {
+			return err
+		}
+		account = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// CreateServiceAccount grava uma nova conta de serviço
+func (r *ServiceAccountRepository) CreateServiceAccount(ctx context.Context, account *model.ServiceAccount) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.CreateServiceAccount")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", account.TenantID.String()),
+		attribute.String("service_account.id", account.ID.String()),
+	)
+
+	cluster, namespace, name := workloadIdentityColumns(account.WorkloadIdentity)
+	query := `
+		INSERT INTO service_accounts (
+			id, tenant_id, name, description, status, k8s_cluster, k8s_namespace, k8s_service_account,
+			created_by, updated_by, created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			account.ID, account.TenantID, account.Name, account.Description, string(account.Status),
+			cluster, namespace, name, account.CreatedBy, account.UpdatedBy, account.CreatedAt, account.UpdatedAt,
+		)
+		if err != nil {
+			return serviceAccountWriteError(err, "erro ao inserir conta de serviço")
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateServiceAccount altera o nome, a descrição, o estado e a ligação Kubernetes de uma conta
+func (r *ServiceAccountRepository) UpdateServiceAccount(ctx context.Context, account *model.ServiceAccount) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.UpdateServiceAccount")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", account.TenantID.String()),
+		attribute.String("service_account.id", account.ID.String()),
+	)
+
+	cluster, namespace, name := workloadIdentityColumns(account.WorkloadIdentity)
+	query := `
+		UPDATE service_accounts SET
+			name = $3, description = NULLIF($4, ''), status = $5, k8s_cluster = $6, k8s_namespace = $7,
+			k8s_service_account = $8, updated_by = $9, updated_at = $10
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			account.TenantID, account.ID, account.Name, account.Description, string(account.Status),
+			cluster, namespace, name, account.UpdatedBy, account.UpdatedAt,
+		)
+		if err != nil {
+			return serviceAccountWriteError(err, "erro ao atualizar conta de serviço")
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrServiceAccountNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// BindRole atribui uma função do tenant à conta de serviço
+func (r *ServiceAccountRepository) BindRole(ctx context.Context, tenantID, accountID, roleID, actorID uuid.UUID, grantedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.BindRole")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("role.id", roleID.String()),
+	)
+
+	// A função e a conta têm de pertencer ao tenant; a atribuição repetida não tem efeito
+	query := `
+		INSERT INTO service_account_roles (service_account_id, role_id, tenant_id, granted_by, granted_at)
+		SELECT a.id, ro.id, a.tenant_id, $4, $5
+		FROM service_accounts a, roles ro
+		WHERE a.tenant_id = $1 AND a.id = $2 AND ro.tenant_id = $1 AND ro.id = $3
+		ON CONFLICT (service_account_id, role_id) DO NOTHING
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var accountExists, roleExists bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM service_accounts WHERE tenant_id = $1 AND id = $2),
+				EXISTS (SELECT 1 FROM roles WHERE tenant_id = $1 AND id = $3)
+		`, tenantID, accountID, roleID).Scan(&accountExists, &roleExists); err != nil {
+			return fmt.Errorf("erro ao verificar conta de serviço e função: %w", err)
+		}
+		if !accountExists {
+			return model.ErrServiceAccountNotFound
+		}
+		if !roleExists {
+			return model.ErrServiceAccountRoleNotFound
+		}
+
+		if _, err := tx.Exec(ctx, query, tenantID, accountID, roleID, actorID, grantedAt); err != nil {
+			return fmt.Errorf("erro ao atribuir função à conta de serviço: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UnbindRole retira uma função da conta de serviço
+func (r *ServiceAccountRepository) UnbindRole(ctx context.Context, tenantID, accountID, roleID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.UnbindRole")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("role.id", roleID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM service_account_roles
+			WHERE tenant_id = $1 AND service_account_id = $2 AND role_id = $3
+		`, tenantID, accountID, roleID)
+		if err != nil {
+			return fmt.Errorf("erro ao retirar função da conta de serviço: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrServiceAccountRoleNotBound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListCredentials recupera as credenciais da conta, revogadas ou não, mais recentes primeiro
+func (r *ServiceAccountRepository) ListCredentials(ctx context.Context, tenantID, accountID uuid.UUID) ([]*model.ServiceAccountCredential, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.ListCredentials")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+	)
+
+	query := `SELECT ` + serviceAccountCredentialColumns + `
+		FROM service_account_credentials
+		WHERE tenant_id = $1 AND service_account_id = $2
+		ORDER BY created_at DESC
+	`
+
+	var credentials []*model.ServiceAccountCredential
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, accountID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar credenciais da conta de serviço: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			credential, err := scanServiceAccountCredential(rows)
+			if err != nil {
+				return err
+			}
+			credentials = append(credentials, credential)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// FindCredential recupera, em qualquer tenant, a credencial do tipo com o identificador público indicado
+func (r *ServiceAccountRepository) FindCredential(ctx context.Context, credentialType model.ServiceAccountCredentialType, identifier string) (*model.ServiceAccountCredential, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.FindCredential")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("credential.type", string(credentialType)))
+
+	var column string
+	switch credentialType {
+	case model.CredentialTypeAPIKey:
+		column = "key_prefix"
+	case model.CredentialTypeOIDCClient:
+		column = "client_id"
+	case model.CredentialTypeMTLSCert:
+		column = "certificate_fingerprint"
+	default:
+		return nil, model.ErrServiceAccountCredentialNotFound
+	}
+
+	query := `SELECT ` + serviceAccountCredentialColumns + `
+		FROM service_account_credentials
+		WHERE type = $1 AND ` + column + ` = $2
+	`
+
+	var credential *model.ServiceAccountCredential
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanServiceAccountCredential(tx.QueryRow(ctx, query, string(credentialType), identifier))
+		if err == pgx.ErrNoRows {
+			return model.ErrServiceAccountCredentialNotFound
+		}
+		if err != nil {
+			return err
+		}
+		credential = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// CreateCredential grava uma nova credencial
+func (r *ServiceAccountRepository) CreateCredential(ctx context.Context, credential *model.ServiceAccountCredential) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.CreateCredential")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", credential.TenantID.String()),
+		attribute.String("service_account.id", credential.ServiceAccountID.String()),
+		attribute.String("credential.type", string(credential.Type)),
+	)
+
+	query := `
+		INSERT INTO service_account_credentials (
+			id, tenant_id, service_account_id, type, name, key_prefix, client_id, secret_hash,
+			certificate_fingerprint, certificate_subject, expires_at, created_by, created_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, NULLIF($9, ''), NULLIF($10, ''), $11, $12, $13)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			credential.ID, credential.TenantID, credential.ServiceAccountID, string(credential.Type), credential.Name,
+			credential.KeyPrefix, credential.ClientID, credential.SecretHash, credential.CertificateFingerprint,
+			credential.CertificateSubject, credential.ExpiresAt, credential.CreatedBy, credential.CreatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrServiceAccountCredentialConflict
+			}
+			return fmt.Errorf("erro ao inserir credencial da conta de serviço: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeCredential revoga uma credencial ativa da conta
+func (r *ServiceAccountRepository) RevokeCredential(ctx context.Context, tenantID, accountID, credentialID, actorID uuid.UUID, revokedAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.RevokeCredential")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+		attribute.String("credential.id", credentialID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE service_account_credentials SET revoked_by = $4, revoked_at = $5
+			WHERE tenant_id = $1 AND service_account_id = $2 AND id = $3 AND revoked_at IS NULL
+		`, tenantID, accountID, credentialID, actorID, revokedAt)
+		if err != nil {
+			return fmt.Errorf("erro ao revogar credencial da conta de serviço: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrServiceAccountCredentialNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordUsage contabiliza uma autenticação no dia e método e atualiza a última utilização
+// da conta e da credencial
+func (r *ServiceAccountRepository) RecordUsage(ctx context.Context, usage *model.ServiceAccountUsage) error {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.RecordUsage")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", usage.TenantID.String()),
+		attribute.String("service_account.id", usage.ServiceAccountID.String()),
+		attribute.String("service_account.auth_method", string(usage.Method)),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO service_account_usage (tenant_id, service_account_id, day, method, authentications)
+			VALUES ($1, $2, ($3::TIMESTAMPTZ AT TIME ZONE 'UTC')::DATE, $4, 1)
+			ON CONFLICT (service_account_id, day, method)
+			DO UPDATE SET authentications = service_account_usage.authentications + 1
+		`, usage.TenantID, usage.ServiceAccountID, usage.UsedAt, string(usage.Method)); err != nil {
+			return fmt.Errorf("erro ao contabilizar utilização da conta de serviço: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE service_accounts SET last_used_at = GREATEST(COALESCE(last_used_at, $3), $3)
+			WHERE tenant_id = $1 AND id = $2
+		`, usage.TenantID, usage.ServiceAccountID, usage.UsedAt); err != nil {
+			return fmt.Errorf("erro ao atualizar última utilização da conta de serviço: %w", err)
+		}
+
+		if usage.CredentialID != nil {
+			if _, err := tx.Exec(ctx, `
+				UPDATE service_account_credentials SET last_used_at = GREATEST(COALESCE(last_used_at, $3), $3)
+				WHERE tenant_id = $1 AND id = $2
+			`, usage.TenantID, *usage.CredentialID, usage.UsedAt); err != nil {
+				return fmt.Errorf("erro ao atualizar última utilização da credencial: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListDailyUsage recupera a utilização diária das contas do tenant desde o dia indicado
+func (r *ServiceAccountRepository) ListDailyUsage(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]*model.ServiceAccountDailyUsage, error) {
+	ctx, span := tracer.Start(ctx, "ServiceAccountRepository.ListDailyUsage")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT service_account_id, day::TIMESTAMP AT TIME ZONE 'UTC', method, authentications
+		FROM service_account_usage
+		WHERE tenant_id = $1 AND day >= ($2::TIMESTAMPTZ AT TIME ZONE 'UTC')::DATE
+		ORDER BY day, service_account_id, method
+	`
+
+	var usage []*model.ServiceAccountDailyUsage
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, since)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar utilização das contas de serviço: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				day    model.ServiceAccountDailyUsage
+				method string
+			)
+			if err := rows.Scan(&day.ServiceAccountID, &day.Day, &method, &day.Authentications); err != nil {
+				return fmt.Errorf("erro ao ler utilização das contas de serviço: %w", err)
+			}
+			day.Day = day.Day.UTC()
+			day.Method = model.ServiceAccountAuthMethod(method)
+			usage = append(usage, &day)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// scanServiceAccount lê as colunas de serviceAccountColumns
+func scanServiceAccount(row pgx.Row) (*model.ServiceAccount, error) {
+	var (
+		account                   model.ServiceAccount
+		status                    string
+		cluster, namespace, k8sSA string
+	)
+	err := row.Scan(
+		&account.ID, &account.TenantID, &account.Name, &account.Description, &status,
+		&cluster, &namespace, &k8sSA, &account.RoleIDs, &account.LastUsedAt,
+		&account.CreatedBy, &account.UpdatedBy, &account.CreatedAt, &account.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler conta de serviço: %w", err)
+	}
+	account.Status = model.ServiceAccountStatus(status)
+	if cluster != "" {
+		account.WorkloadIdentity = &model.WorkloadIdentityBinding{Cluster: cluster, Namespace: namespace, ServiceAccount: k8sSA}
+	}
+	return &account, nil
+}
+
+// scanServiceAccountCredential lê as colunas de serviceAccountCredentialColumns
+func scanServiceAccountCredential(row pgx.Row) (*model.ServiceAccountCredential, error) {
+	var (
+		credential     model.ServiceAccountCredential
+		credentialType string
+	)
+	err := row.Scan(
+		&credential.ID, &credential.TenantID, &credential.ServiceAccountID, &credentialType, &credential.Name,
+		&credential.KeyPrefix, &credential.ClientID, &credential.SecretHash, &credential.CertificateFingerprint,
+		&credential.CertificateSubject, &credential.ExpiresAt, &credential.LastUsedAt, &credential.CreatedBy,
+		&credential.CreatedAt, &credential.RevokedBy, &credential.RevokedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler credencial da conta de serviço: %w", err)
+	}
+	credential.Type = model.ServiceAccountCredentialType(credentialType)
+	return &credential, nil
+}
+
+// workloadIdentityColumns retorna as colunas da ligação Kubernetes, nulas quando a conta não está ligada
+func workloadIdentityColumns(binding *model.WorkloadIdentityBinding) (cluster, namespace, name *string) {
+	if binding == nil {
+		return nil, nil, nil
+	}
+	return &binding.Cluster, &binding.Namespace, &binding.ServiceAccount
+}
+
+// serviceAccountWriteError distingue as violações de unicidade do nome e da ligação Kubernetes
+func serviceAccountWriteError(err error, message string) error {
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+		if pgErr.ConstraintName == "uq_service_accounts_workload" {
+			return model.ErrWorkloadIdentityConflict
+		}
+		return model.ErrServiceAccountConflict
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// SessionPolicyRepository implementa a interface repository.SessionPolicyRepository usando PostgreSQL
+type SessionPolicyRepository struct {
+	db *DB
+}
+
+// NewSessionPolicyRepository cria uma nova instância do SessionPolicyRepository
+func NewSessionPolicyRepository(db *DB) *SessionPolicyRepository {
+	return &SessionPolicyRepository{db: db}
+}
+
+// GetPolicy recupera a política de sessão do tenant, ou nil se não existir
+func (r *SessionPolicyRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.SessionPolicy, error) {
+	ctx, span := tracer.Start(ctx, "SessionPolicyRepository.GetPolicy")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT tenant_id, COALESCE(market, ''), financial_tenant, access_token_ttl_seconds,
+			refresh_token_ttl_seconds, absolute_lifetime_seconds, idle_timeout_seconds,
+			COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM session_policies
+		WHERE tenant_id = $1
+	`
+
+	var policy *model.SessionPolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var found model.SessionPolicy
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&found.TenantID, &found.Market, &found.FinancialTenant, &found.AccessTokenTTLSeconds,
+			&found.RefreshTokenTTLSeconds, &found.AbsoluteLifetimeSeconds, &found.IdleTimeoutSeconds,
+			&found.UpdatedBy, &found.UpdatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar política de sessão: %w", err)
+		}
+		policy = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// SavePolicy grava a política de sessão do tenant, substituindo a anterior
+func (r *SessionPolicyRepository) SavePolicy(ctx context.Context, policy *model.SessionPolicy) error {
+	ctx, span := tracer.Start(ctx, "SessionPolicyRepository.SavePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.String("session_policy.market", policy.Market),
+	)
+
+	query := `
+		INSERT INTO session_policies (
+			tenant_id, market, financial_tenant, access_token_ttl_seconds, refresh_token_ttl_seconds,
+			absolute_lifetime_seconds, idle_timeout_seconds, updated_by, updated_at
+		) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			market = EXCLUDED.market,
+			financial_tenant = EXCLUDED.financial_tenant,
+			access_token_ttl_seconds = EXCLUDED.access_token_ttl_seconds,
+			refresh_token_ttl_seconds = EXCLUDED.refresh_token_ttl_seconds,
+			absolute_lifetime_seconds = EXCLUDED.absolute_lifetime_seconds,
+			idle_timeout_seconds = EXCLUDED.idle_timeout_seconds,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			policy.TenantID, policy.Market, policy.FinancialTenant, policy.AccessTokenTTLSeconds,
+			policy.RefreshTokenTTLSeconds, policy.AbsoluteLifetimeSeconds, policy.IdleTimeoutSeconds,
+			policy.UpdatedBy, policy.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar política de sessão: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePolicy remove a política de sessão do tenant; sem política não há nada a remover
+func (r *SessionPolicyRepository) DeletePolicy(ctx context.Context, tenantID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "SessionPolicyRepository.DeletePolicy")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM session_policies WHERE tenant_id = $1`, tenantID); err != nil {
+			return fmt.Errorf("erro ao remover política de sessão: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// TouchSession regista um pedido da sessão, terminando-a se estiver inativa desde idleSince
+// O registo é bloqueado durante a transação para que pedidos simultâneos não se sobreponham
+func (r *SessionPolicyRepository) TouchSession(ctx context.Context, activity *model.SessionActivity, idleSince time.Time) (*model.SessionActivity, error) {
+	ctx, span := tracer.Start(ctx, "SessionPolicyRepository.TouchSession")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", activity.TenantID.String()),
+		attribute.String("user.id", activity.UserID.String()),
+	)
+
+	selectQuery := `
+		SELECT tenant_id, session_id, user_id, started_at, last_seen_at, ended_at
+		FROM session_activity
+		WHERE tenant_id = $1 AND session_id = $2
+		FOR UPDATE
+	`
+
+	var result *model.SessionActivity
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var current model.SessionActivity
+		err := tx.QueryRow(ctx, selectQuery, activity.TenantID, activity.SessionID).Scan(
+			&current.TenantID, &current.SessionID, &current.UserID,
+			&current.StartedAt, &current.LastSeenAt, &current.EndedAt,
+		)
+		if err == pgx.ErrNoRows {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO session_activity (tenant_id, session_id, user_id, started_at, last_seen_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (tenant_id, session_id) DO NOTHING
+			`, activity.TenantID, activity.SessionID, activity.UserID, activity.StartedAt, activity.LastSeenAt)
+			if err != nil {
+				return fmt.Errorf("erro ao registar sessão: %w", err)
+			}
+			registered := *activity
+			result = &registered
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar atividade da sessão: %w", err)
+		}
+
+		if current.EndedAt == nil && current.LastSeenAt.Before(idleSince) {
+			endedAt := activity.LastSeenAt
+			_, err = tx.Exec(ctx, `
+				UPDATE session_activity SET ended_at = $3
+				WHERE tenant_id = $1 AND session_id = $2
+			`, activity.TenantID, activity.SessionID, endedAt)
+			if err != nil {
+				return fmt.Errorf("erro ao terminar sessão inativa: %w", err)
+			}
+			current.EndedAt = &endedAt
+		}
+		if current.EndedAt != nil {
+			result = &current
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE session_activity SET last_seen_at = GREATEST(last_seen_at, $3)
+			WHERE tenant_id = $1 AND session_id = $2
+		`, activity.TenantID, activity.SessionID, activity.LastSeenAt)
+		if err != nil {
+			return fmt.Errorf("erro ao registar atividade da sessão: %w", err)
+		}
+		if activity.LastSeenAt.After(current.LastSeenAt) {
+			current.LastSeenAt = activity.LastSeenAt
+		}
+		result = &current
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return result, nil
+}
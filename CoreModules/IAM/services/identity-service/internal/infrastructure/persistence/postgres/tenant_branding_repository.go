@@ -0,0 +1,375 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/repository"
+)
+
+// Colunas lidas pelo repositório dos modelos de email
+const emailTemplateColumns = `
+	id, tenant_id, kind, locale, version, status, COALESCE(description, ''), subject, html_body,
+	COALESCE(text_body, ''), COALESCE(created_by, '00000000-0000-0000-0000-000000000000'::UUID), created_at,
+	published_by, published_at
+`
+
+// TenantBrandingRepository implementa a interface repository.TenantBrandingRepository usando PostgreSQL
+type TenantBrandingRepository struct {
+	db *DB
+}
+
+// NewTenantBrandingRepository cria uma nova instância do TenantBrandingRepository
+func NewTenantBrandingRepository(db *DB) *TenantBrandingRepository {
+	return &TenantBrandingRepository{db: db}
+}
+
+// GetBranding recupera a identidade visual do tenant, ou nil se não existir
+func (r *TenantBrandingRepository) GetBranding(ctx context.Context, tenantID uuid.UUID) (*model.TenantBranding, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.GetBranding")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT tenant_id, display_name, COALESCE(logo_url, ''), COALESCE(primary_color, ''),
+			COALESCE(secondary_color, ''), COALESCE(support_email, ''), COALESCE(support_url, ''),
+			COALESCE(footer_text, ''), COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), updated_at
+		FROM tenant_branding
+		WHERE tenant_id = $1
+	`
+
+	var branding *model.TenantBranding
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var found model.TenantBranding
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&found.TenantID, &found.DisplayName, &found.LogoURL, &found.PrimaryColor, &found.SecondaryColor,
+			&found.SupportEmail, &found.SupportURL, &found.FooterText, &found.UpdatedBy, &found.UpdatedAt,
+		)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar identidade visual do tenant: %w", err)
+		}
+		branding = &found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return branding, nil
+}
+
+// SaveBranding grava a identidade visual do tenant, substituindo a anterior
+func (r *TenantBrandingRepository) SaveBranding(ctx context.Context, branding *model.TenantBranding) error {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.SaveBranding")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", branding.TenantID.String()))
+
+	query := `
+		INSERT INTO tenant_branding (
+			tenant_id, display_name, logo_url, primary_color, secondary_color, support_email, support_url,
+			footer_text, updated_by, updated_at
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			logo_url = EXCLUDED.logo_url,
+			primary_color = EXCLUDED.primary_color,
+			secondary_color = EXCLUDED.secondary_color,
+			support_email = EXCLUDED.support_email,
+			support_url = EXCLUDED.support_url,
+			footer_text = EXCLUDED.footer_text,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			branding.TenantID, branding.DisplayName, branding.LogoURL, branding.PrimaryColor, branding.SecondaryColor,
+			branding.SupportEmail, branding.SupportURL, branding.FooterText, branding.UpdatedBy, branding.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar identidade visual do tenant: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateEmailTemplate grava uma nova versão do modelo com o número seguinte ao da última versão
+// O bloqueio consultivo do email e idioma serializa as gravações concorrentes da mesma sequência
+func (r *TenantBrandingRepository) CreateEmailTemplate(ctx context.Context, template *model.EmailTemplate) error {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.CreateEmailTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", template.TenantID.String()),
+		attribute.String("email_template.kind", string(template.Kind)),
+		attribute.String("email_template.locale", template.Locale),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`,
+			"email_template:"+template.TenantID.String()+":"+string(template.Kind)+":"+template.Locale,
+		); err != nil {
+			return fmt.Errorf("erro ao bloquear versões do modelo de email: %w", err)
+		}
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO email_templates (
+				id, tenant_id, kind, locale, version, status, description, subject, html_body, text_body,
+				created_by, created_at
+			)
+			SELECT $1, $2, $3, $4, COALESCE(MAX(version), 0) + 1, $5, NULLIF($6, ''), $7, $8, NULLIF($9, ''), $10, $11
+			FROM email_templates
+			WHERE tenant_id = $2 AND kind = $3 AND locale = $4
+			RETURNING version
+		`,
+			template.ID, template.TenantID, string(template.Kind), template.Locale, string(template.Status),
+			template.Description, template.Subject, template.HTMLBody, template.TextBody,
+			template.CreatedBy, template.CreatedAt,
+		).Scan(&template.Version)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar modelo de email: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetEmailTemplate recupera uma versão de um modelo do tenant
+func (r *TenantBrandingRepository) GetEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.GetEmailTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.id", templateID.String()),
+	)
+
+	var template *model.EmailTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanEmailTemplate(tx.QueryRow(ctx, `
+			SELECT `+emailTemplateColumns+`
+			FROM email_templates
+			WHERE tenant_id = $1 AND id = $2
+		`, tenantID, templateID))
+		if err == pgx.ErrNoRows {
+			return model.ErrEmailTemplateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelo de email: %w", err)
+		}
+		template = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ListEmailTemplates recupera as versões dos modelos do tenant, por email e idioma e da mais
+// recente para a mais antiga
+func (r *TenantBrandingRepository) ListEmailTemplates(ctx context.Context, tenantID uuid.UUID, filter repository.EmailTemplateFilter) ([]*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.ListEmailTemplates")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.kind", string(filter.Kind)),
+		attribute.String("email_template.status", string(filter.Status)),
+	)
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	if filter.Kind != "" {
+		args = append(args, string(filter.Kind))
+		conditions = append(conditions, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	if filter.Locale != "" {
+		args = append(args, filter.Locale)
+		conditions = append(conditions, fmt.Sprintf("locale = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	query := `
+		SELECT ` + emailTemplateColumns + `
+		FROM email_templates
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY kind, locale, version DESC
+	`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	var templates []*model.EmailTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelos de email: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			template, err := scanEmailTemplate(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler modelo de email: %w", err)
+			}
+			templates = append(templates, template)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// PublishEmailTemplate publica uma versão numa transação, arquivando a versão publicada do mesmo email e idioma
+func (r *TenantBrandingRepository) PublishEmailTemplate(ctx context.Context, tenantID, templateID, publishedBy uuid.UUID, publishedAt time.Time) (*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.PublishEmailTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.id", templateID.String()),
+	)
+
+	var template *model.EmailTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanEmailTemplate(tx.QueryRow(ctx, `
+			SELECT `+emailTemplateColumns+`
+			FROM email_templates
+			WHERE tenant_id = $1 AND id = $2
+			FOR UPDATE
+		`, tenantID, templateID))
+		if err == pgx.ErrNoRows {
+			return model.ErrEmailTemplateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar modelo de email: %w", err)
+		}
+
+		// A versão publicada é arquivada antes, para respeitar o índice de uma publicação por email e idioma
+		if _, err := tx.Exec(ctx, `
+			UPDATE email_templates SET status = 'archived'
+			WHERE tenant_id = $1 AND kind = $2 AND locale = $3 AND status = 'published' AND id <> $4
+		`, tenantID, string(found.Kind), found.Locale, templateID); err != nil {
+			return fmt.Errorf("erro ao arquivar versão publicada do modelo de email: %w", err)
+		}
+
+		template, err = scanEmailTemplate(tx.QueryRow(ctx, `
+			UPDATE email_templates SET status = 'published', published_by = $3, published_at = $4
+			WHERE tenant_id = $1 AND id = $2
+			RETURNING `+emailTemplateColumns,
+			tenantID, templateID, publishedBy, publishedAt,
+		))
+		if err != nil {
+			return fmt.Errorf("erro ao publicar modelo de email: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// ArchiveEmailTemplate arquiva uma versão do tenant
+func (r *TenantBrandingRepository) ArchiveEmailTemplate(ctx context.Context, tenantID, templateID uuid.UUID) (*model.EmailTemplate, error) {
+	ctx, span := tracer.Start(ctx, "TenantBrandingRepository.ArchiveEmailTemplate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.id", templateID.String()),
+	)
+
+	var template *model.EmailTemplate
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanEmailTemplate(tx.QueryRow(ctx, `
+			UPDATE email_templates SET status = 'archived'
+			WHERE tenant_id = $1 AND id = $2
+			RETURNING `+emailTemplateColumns,
+			tenantID, templateID,
+		))
+		if err == pgx.ErrNoRows {
+			return model.ErrEmailTemplateNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao arquivar modelo de email: %w", err)
+		}
+		template = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// scanEmailTemplate lê uma versão de um modelo de email nas colunas emailTemplateColumns
+func scanEmailTemplate(row pgx.Row) (*model.EmailTemplate, error) {
+	var (
+		template     model.EmailTemplate
+		kind, status string
+	)
+	if err := row.Scan(
+		&template.ID, &template.TenantID, &kind, &template.Locale, &template.Version, &status,
+		&template.Description, &template.Subject, &template.HTMLBody, &template.TextBody,
+		&template.CreatedBy, &template.CreatedAt, &template.PublishedBy, &template.PublishedAt,
+	); err != nil {
+		return nil, err
+	}
+	template.Kind = model.EmailTemplateKind(kind)
+	template.Status = model.EmailTemplateStatus(status)
+	return &template, nil
+}
@@ -0,0 +1,457 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório de exportações de tenants
+const tenantExportColumns = `
+	id, tenant_id, market, format, pii_mode, status, progress, manifest,
+	COALESCE(error, ''), requested_by, attempts, version, created_at, updated_at, completed_at
+`
+
+// Número máximo de exportações devolvidas quando o limite não é indicado
+const defaultTenantExportListLimit = 50
+
+// Separador dos valores da chave no cursor de uma secção
+const tenantExportCursorSeparator = "|"
+
+// tenantExportSectionQuery descreve a leitura de uma secção do arquivo
+// As colunas acompanham model.TenantExportSection.Columns; identificadores e valores
+// estruturados são lidos como texto
+type tenantExportSectionQuery struct {
+	from         string
+	tenantColumn string
+	keys         []string
+	keyTypes     []string
+	columns      []string
+}
+
+// tenantExportSectionQueries define a leitura de cada secção, ordenada pela chave
+// As atribuições sem tenant_id próprio são filtradas pelo tenant da função ou do usuário
+var tenantExportSectionQueries = map[model.TenantExportSection]tenantExportSectionQuery{
+	model.TenantExportSectionUsers: {
+		from: "users u", tenantColumn: "u.tenant_id",
+		keys: []string{"u.id"}, keyTypes: []string{"uuid"},
+		columns: []string{
+			"u.id::text", "u.username", "u.email", "u.email_verified", "u.first_name", "u.last_name",
+			"u.display_name", "u.phone_number", "u.phone_verified", "u.profile_picture_url", "u.locale",
+			"u.timezone", "u.status", "u.status_reason", "u.metadata::text", "u.login_count", "u.last_login_at",
+			"u.created_at", "u.updated_at", "u.deleted_at",
+		},
+	},
+	model.TenantExportSectionRoles: {
+		from: "roles r", tenantColumn: "r.tenant_id",
+		keys: []string{"r.id"}, keyTypes: []string{"uuid"},
+		columns: []string{"r.id::text", "r.name", "r.description", "r.is_system", "r.created_at", "r.updated_at"},
+	},
+	model.TenantExportSectionPermissions: {
+		from: "permissions p", tenantColumn: "p.tenant_id",
+		keys: []string{"p.id"}, keyTypes: []string{"uuid"},
+		columns: []string{
+			"p.id::text", "p.resource", "p.action", "p.description", "p.is_system", "p.created_at", "p.updated_at",
+		},
+	},
+	model.TenantExportSectionRolePermissions: {
+		from: "role_permissions rp JOIN roles r ON r.id = rp.role_id", tenantColumn: "r.tenant_id",
+		keys: []string{"rp.role_id", "rp.permission_id"}, keyTypes: []string{"uuid", "uuid"},
+		columns: []string{"rp.role_id::text", "rp.permission_id::text", "rp.created_at"},
+	},
+	model.TenantExportSectionUserRoles: {
+		from: "user_roles ur JOIN users u ON u.id = ur.user_id", tenantColumn: "u.tenant_id",
+		keys: []string{"ur.user_id", "ur.role_id"}, keyTypes: []string{"uuid", "uuid"},
+		columns: []string{"ur.user_id::text", "ur.role_id::text", "ur.created_at", "ur.created_by::text"},
+	},
+	model.TenantExportSectionUserPermissions: {
+		from: "user_permissions up", tenantColumn: "up.tenant_id",
+		keys: []string{"up.user_id", "up.permission_id"}, keyTypes: []string{"uuid", "uuid"},
+		columns: []string{
+			"up.user_id::text", "up.permission_id::text", "up.expires_at", "up.granted_by::text",
+			"up.access_request_id::text", "up.granted_at",
+		},
+	},
+	model.TenantExportSectionAuditLogs: {
+		from: "audit_logs a", tenantColumn: "a.tenant_id",
+		keys: []string{"a.created_at", "a.id"}, keyTypes: []string{"timestamptz", "uuid"},
+		columns: []string{
+			"a.id::text", "a.event_type", "a.entity_type", "a.entity_id::text", "a.user_id::text", "a.ip_address",
+			"a.user_agent", "a.old_value::text", "a.new_value::text", "a.metadata::text", "a.created_at",
+		},
+	},
+	model.TenantExportSectionRoleEvents: {
+		from: "role_events re", tenantColumn: "re.tenant_id",
+		keys: []string{"re.role_id", "re.version"}, keyTypes: []string{"uuid", "bigint"},
+		columns: []string{
+			"re.id::text", "re.role_id::text", "re.version", "re.event_type", "re.payload::text",
+			"re.actor_id::text", "re.occurred_at", "re.recorded_at",
+		},
+	},
+	model.TenantExportSectionUserLifecycleTransition: {
+		from: "user_lifecycle_transitions t", tenantColumn: "t.tenant_id",
+		keys: []string{"t.occurred_at", "t.id"}, keyTypes: []string{"timestamptz", "uuid"},
+		columns: []string{
+			"t.id::text", "t.user_id::text", "t.from_status", "t.to_status", "t.reason", "t.actor_id::text",
+			"t.occurred_at", "t.recorded_at",
+		},
+	},
+	model.TenantExportSectionSecurityIncidents: {
+		from: "security_incidents si", tenantColumn: "si.tenant_id",
+		keys: []string{"si.detected_at", "si.id"}, keyTypes: []string{"timestamptz", "uuid"},
+		columns: []string{
+			"si.id::text", "si.rule", "si.severity", "si.status", "si.actor_id::text", "si.summary",
+			"si.evidence::text", "si.detected_at", "si.updated_at", "si.triaged_by::text", "si.resolution_note",
+			"si.closed_at",
+		},
+	},
+}
+
+// TenantExportRepository implementa a interface repository.TenantExportRepository usando PostgreSQL
+type TenantExportRepository struct {
+	db *DB
+}
+
+// NewTenantExportRepository cria uma nova instância do TenantExportRepository
+func NewTenantExportRepository(db *DB) *TenantExportRepository {
+	return &TenantExportRepository{db: db}
+}
+
+// Create grava uma nova exportação
+func (r *TenantExportRepository) Create(ctx context.Context, export *model.TenantExport) error {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_export.id", export.ID.String()),
+		attribute.String("tenant.id", export.TenantID.String()),
+	)
+
+	progress, err := json.Marshal(export.Progress)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar progresso da exportação: %w", err)
+	}
+
+	query := `
+		INSERT INTO tenant_exports (
+			id, tenant_id, market, format, pii_mode, status, progress, requested_by,
+			attempts, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			export.ID, export.TenantID, export.Market, string(export.Format), string(export.PIIMode),
+			string(export.Status), progress, export.RequestedBy, export.Attempts, export.Version,
+			export.CreatedAt, export.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrTenantExportInProgress
+			}
+			return fmt.Errorf("erro ao inserir exportação de tenant: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Get recupera uma exportação pelo ID
+func (r *TenantExportRepository) Get(ctx context.Context, tenantID, exportID uuid.UUID) (*model.TenantExport, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_export.id", exportID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + tenantExportColumns + `
+		FROM tenant_exports
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var export *model.TenantExport
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		export, err = scanTenantExport(tx.QueryRow(ctx, query, tenantID, exportID))
+		if err == pgx.ErrNoRows {
+			return model.ErrTenantExportNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar exportação de tenant: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// List recupera as exportações do tenant, da mais recente para a mais antiga
+func (r *TenantExportRepository) List(ctx context.Context, tenantID uuid.UUID, limit int) ([]*model.TenantExport, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	if limit <= 0 {
+		limit = defaultTenantExportListLimit
+	}
+	query := `SELECT ` + tenantExportColumns + `
+		FROM tenant_exports
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	exports, err := r.queryExports(ctx, query, tenantID, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// ListActive recupera as exportações pendentes ou em geração de todos os tenants
+func (r *TenantExportRepository) ListActive(ctx context.Context, limit int) ([]*model.TenantExport, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.ListActive")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultTenantExportListLimit
+	}
+	query := `SELECT ` + tenantExportColumns + `
+		FROM tenant_exports
+		WHERE status IN ('pending', 'running')
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+
+	exports, err := r.queryExports(ctx, query, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// Save grava o estado, o progresso e o manifesto da exportação e incrementa a sua versão
+func (r *TenantExportRepository) Save(ctx context.Context, export *model.TenantExport, expectedVersion int64) error {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.Save")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_export.id", export.ID.String()),
+		attribute.String("tenant.id", export.TenantID.String()),
+		attribute.String("tenant_export.status", string(export.Status)),
+	)
+
+	progress, err := json.Marshal(export.Progress)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar progresso da exportação: %w", err)
+	}
+	var manifest []byte
+	if export.Manifest != nil {
+		if manifest, err = json.Marshal(export.Manifest); err != nil {
+			return fmt.Errorf("erro ao serializar manifesto da exportação: %w", err)
+		}
+	}
+
+	// A condição sobre a versão impede que duas instâncias gerem a mesma exportação
+	query := `
+		UPDATE tenant_exports
+		SET status = $3, progress = $4, manifest = $5, error = NULLIF($6, ''), attempts = $7,
+			version = version + 1, updated_at = $8, completed_at = $9
+		WHERE tenant_id = $1 AND id = $2 AND version = $10
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			export.TenantID, export.ID, string(export.Status), progress, manifest, export.Error, export.Attempts,
+			export.UpdatedAt, export.CompletedAt, expectedVersion,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar exportação de tenant: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: exportação %s não está na versão %d", model.ErrTenantExportConflict, export.ID, expectedVersion)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	export.Version = expectedVersion + 1
+	return nil
+}
+
+// ReadSection recupera até limit linhas da secção do tenant a partir da linha seguinte ao cursor
+func (r *TenantExportRepository) ReadSection(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	section model.TenantExportSection,
+	cursor string,
+	limit int,
+) ([]model.TenantExportRecord, error) {
+	ctx, span := tracer.Start(ctx, "TenantExportRepository.ReadSection")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("tenant_export.section", string(section)),
+		attribute.Int("limit", limit),
+	)
+
+	spec, ok := tenantExportSectionQueries[section]
+	if !ok {
+		return nil, fmt.Errorf("%w: secção desconhecida %q", model.ErrInvalidTenantExport, section)
+	}
+
+	keyText := make([]string, len(spec.keys))
+	for i, key := range spec.keys {
+		keyText[i] = key + "::text"
+	}
+
+	conditions := []string{spec.tenantColumn + " = $1"}
+	args := []interface{}{tenantID}
+	if cursor != "" {
+		values := strings.Split(cursor, tenantExportCursorSeparator)
+		if len(values) != len(spec.keys) {
+			return nil, fmt.Errorf("%w: cursor inválido para a secção %s", model.ErrInvalidTenantExport, section)
+		}
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d::%s", len(args), spec.keyTypes[i])
+		}
+		conditions = append(conditions,
+			"("+strings.Join(spec.keys, ", ")+") > ("+strings.Join(placeholders, ", ")+")")
+	}
+	args = append(args, limit)
+
+	query := `SELECT concat_ws('` + tenantExportCursorSeparator + `', ` + strings.Join(keyText, ", ") + `), ` +
+		strings.Join(spec.columns, ", ") + `
+		FROM ` + spec.from + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + strings.Join(spec.keys, ", ") + `
+		LIMIT $` + fmt.Sprint(len(args))
+
+	columns := section.Columns()
+	var records []model.TenantExportRecord
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar secção %s da exportação: %w", section, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				return fmt.Errorf("erro ao ler secção %s da exportação: %w", section, err)
+			}
+
+			record := model.TenantExportRecord{Key: fmt.Sprint(values[0]), Values: values[1:]}
+			for i, column := range columns {
+				if text, ok := record.Values[i].(string); ok && column.Structured {
+					record.Values[i] = json.RawMessage(text)
+				}
+			}
+			records = append(records, record)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("records", len(records)))
+	return records, nil
+}
+
+// queryExports executa uma consulta de exportações com as colunas de tenantExportColumns
+func (r *TenantExportRepository) queryExports(ctx context.Context, query string, args ...interface{}) ([]*model.TenantExport, error) {
+	var exports []*model.TenantExport
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar exportações de tenant: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			export, err := scanTenantExport(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler exportação de tenant: %w", err)
+			}
+			exports = append(exports, export)
+		}
+		return rows.Err()
+	})
+	return exports, err
+}
+
+// scanTenantExport lê uma exportação a partir de uma linha com as colunas de tenantExportColumns
+func scanTenantExport(row pgx.Row) (*model.TenantExport, error) {
+	var (
+		export                  model.TenantExport
+		format, piiMode, status string
+		progress, manifest      []byte
+	)
+	err := row.Scan(
+		&export.ID, &export.TenantID, &export.Market, &format, &piiMode, &status, &progress, &manifest,
+		&export.Error, &export.RequestedBy, &export.Attempts, &export.Version, &export.CreatedAt,
+		&export.UpdatedAt, &export.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(progress, &export.Progress); err != nil {
+		return nil, fmt.Errorf("erro ao deserializar progresso da exportação: %w", err)
+	}
+	if manifest != nil {
+		export.Manifest = &model.TenantExportManifest{}
+		if err := json.Unmarshal(manifest, export.Manifest); err != nil {
+			return nil, fmt.Errorf("erro ao deserializar manifesto da exportação: %w", err)
+		}
+	}
+	export.Format = model.TenantExportFormat(format)
+	export.PIIMode = model.TenantExportPIIMode(piiMode)
+	export.Status = model.TenantExportStatus(status)
+	return &export, nil
+}
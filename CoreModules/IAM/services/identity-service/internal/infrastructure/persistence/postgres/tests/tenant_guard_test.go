@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+	"innovabiz/iam/identity-service/internal/infrastructure/persistence/postgres"
+)
+
+// TestQueriesRequireTenant verifica que as consultas sem tenant no contexto são recusadas
+// antes de qualquer acesso ao pool de conexões
+func TestQueriesRequireTenant(t *testing.T) {
+	db := &postgres.DB{}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "sem contexto do pedido", ctx: context.Background()},
+		{name: "apenas mercado", ctx: requestctx.WithMarket(context.Background(), "angola")},
+		{name: "tenant nulo", ctx: requestctx.WithTenant(context.Background(), uuid.Nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec(tt.ctx, "DELETE FROM iam.permissions")
+			assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+
+			rows, err := db.Query(tt.ctx, "SELECT id FROM iam.permissions")
+			assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+			assert.Nil(t, rows)
+
+			var id uuid.UUID
+			err = db.QueryRow(tt.ctx, "SELECT id FROM iam.permissions LIMIT 1").Scan(&id)
+			assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+
+			called := false
+			err = db.InTransaction(tt.ctx, func(ctx context.Context, tx pgx.Tx) error {
+				called = true
+				return nil
+			})
+			assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+			assert.False(t, called)
+		})
+	}
+}
+
+// TestPermissionRepositoryRequiresTenant verifica que o repositório de permissões não consulta
+// o banco sem tenant no contexto
+func TestPermissionRepositoryRequiresTenant(t *testing.T) {
+	repo := postgres.NewPermissionRepository(&postgres.DB{})
+
+	_, err := repo.FindByID(context.Background(), uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+
+	err = repo.HardDelete(context.Background(), uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+}
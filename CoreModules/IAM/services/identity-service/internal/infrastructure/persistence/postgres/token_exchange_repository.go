@@ -0,0 +1,382 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório da troca de tokens
+const tokenExchangePolicyColumns = `
+	id, tenant_id, audience, COALESCE(description, ''), allowed_actors, allowed_scopes, max_ttl_seconds,
+	max_delegation_depth, enabled,
+	COALESCE(created_by, '00000000-0000-0000-0000-000000000000'::UUID),
+	COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), created_at, updated_at
+`
+
+const tokenExchangeEventColumns = `
+	id, tenant_id, subject, actor, actor_chain, audience, policy_id, scopes, outcome, COALESCE(reason, ''),
+	COALESCE(token_id, ''), expires_at, COALESCE(ip_address, ''), occurred_at
+`
+
+// TokenExchangeRepository implementa a interface repository.TokenExchangeRepository usando PostgreSQL
+type TokenExchangeRepository struct {
+	db *DB
+}
+
+// NewTokenExchangeRepository cria uma nova instância do TokenExchangeRepository
+func NewTokenExchangeRepository(db *DB) *TokenExchangeRepository {
+	return &TokenExchangeRepository{db: db}
+}
+
+// ListPolicies recupera todas as políticas do tenant, ordenadas pela audiência
+func (r *TokenExchangeRepository) ListPolicies(ctx context.Context, tenantID uuid.UUID) ([]*model.TokenExchangePolicy, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.ListPolicies")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + tokenExchangePolicyColumns + `
+		FROM token_exchange_policies
+		WHERE tenant_id = $1
+		ORDER BY audience
+	`
+
+	var policies []*model.TokenExchangePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar políticas de troca de tokens: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			policy, err := scanTokenExchangePolicy(rows)
+			if err != nil {
+				return err
+			}
+			policies = append(policies, policy)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// GetPolicy recupera uma política do tenant
+func (r *TokenExchangeRepository) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*model.TokenExchangePolicy, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.GetPolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("token_exchange_policy.id", policyID.String()),
+	)
+
+	query := `SELECT ` + tokenExchangePolicyColumns + `
+		FROM token_exchange_policies
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var policy *model.TokenExchangePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanTokenExchangePolicy(tx.QueryRow(ctx, query, tenantID, policyID))
+		if err == pgx.ErrNoRows {
+			return model.ErrTokenExchangePolicyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		policy = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// GetPolicyByAudience recupera a política do tenant para a audiência
+func (r *TokenExchangeRepository) GetPolicyByAudience(ctx context.Context, tenantID uuid.UUID, audience string) (*model.TokenExchangePolicy, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.GetPolicyByAudience")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("token_exchange.audience", audience),
+	)
+
+	query := `SELECT ` + tokenExchangePolicyColumns + `
+		FROM token_exchange_policies
+		WHERE tenant_id = $1 AND audience = $2
+	`
+
+	var policy *model.TokenExchangePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanTokenExchangePolicy(tx.QueryRow(ctx, query, tenantID, audience))
+		if err == pgx.ErrNoRows {
+			return model.ErrTokenExchangePolicyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		policy = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// CreatePolicy grava uma nova política
+func (r *TokenExchangeRepository) CreatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.CreatePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.String("token_exchange_policy.id", policy.ID.String()),
+	)
+
+	query := `
+		INSERT INTO token_exchange_policies (
+			id, tenant_id, audience, description, allowed_actors, allowed_scopes, max_ttl_seconds,
+			max_delegation_depth, enabled, created_by, updated_by, created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			policy.ID, policy.TenantID, policy.Audience, policy.Description, policy.AllowedActors,
+			policy.AllowedScopes, policy.MaxTTLSeconds, policy.MaxDelegationDepth, policy.Enabled,
+			policy.CreatedBy, policy.UpdatedBy, policy.CreatedAt, policy.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrTokenExchangePolicyConflict
+			}
+			return fmt.Errorf("erro ao inserir política de troca de tokens: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePolicy altera uma política existente
+func (r *TokenExchangeRepository) UpdatePolicy(ctx context.Context, policy *model.TokenExchangePolicy) error {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.UpdatePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", policy.TenantID.String()),
+		attribute.String("token_exchange_policy.id", policy.ID.String()),
+	)
+
+	query := `
+		UPDATE token_exchange_policies SET
+			audience = $3, description = NULLIF($4, ''), allowed_actors = $5, allowed_scopes = $6,
+			max_ttl_seconds = $7, max_delegation_depth = $8, enabled = $9, updated_by = $10, updated_at = $11
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query,
+			policy.TenantID, policy.ID, policy.Audience, policy.Description, policy.AllowedActors,
+			policy.AllowedScopes, policy.MaxTTLSeconds, policy.MaxDelegationDepth, policy.Enabled,
+			policy.UpdatedBy, policy.UpdatedAt,
+		)
+		if err != nil {
+			if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+				return model.ErrTokenExchangePolicyConflict
+			}
+			return fmt.Errorf("erro ao atualizar política de troca de tokens: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrTokenExchangePolicyNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeletePolicy remove uma política do tenant
+func (r *TokenExchangeRepository) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.DeletePolicy")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("token_exchange_policy.id", policyID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM token_exchange_policies WHERE tenant_id = $1 AND id = $2`, tenantID, policyID)
+		if err != nil {
+			return fmt.Errorf("erro ao remover política de troca de tokens: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrTokenExchangePolicyNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// SaveEvent acrescenta uma troca ao registo de auditoria
+func (r *TokenExchangeRepository) SaveEvent(ctx context.Context, event *model.TokenExchangeEvent) error {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.SaveEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", event.TenantID.String()),
+		attribute.String("token_exchange.audience", event.Audience),
+		attribute.String("token_exchange.outcome", string(event.Outcome)),
+	)
+
+	query := `
+		INSERT INTO token_exchange_events (
+			id, tenant_id, subject, actor, actor_chain, audience, policy_id, scopes, outcome, reason,
+			token_id, expires_at, ip_address, occurred_at
+		) VALUES ($1, $2, $3, $4, COALESCE($5::TEXT[], '{}'), $6, $7, COALESCE($8::TEXT[], '{}'), $9, NULLIF($10, ''), NULLIF($11, ''), $12, NULLIF($13, ''), $14)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			event.ID, event.TenantID, event.Subject, event.Actor, event.ActorChain, event.Audience,
+			event.PolicyID, event.Scopes, string(event.Outcome), event.Reason, event.TokenID,
+			event.ExpiresAt, event.IPAddress, event.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao registar troca de tokens: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListEvents recupera o registo de auditoria do tenant, mais recentes primeiro
+func (r *TokenExchangeRepository) ListEvents(ctx context.Context, tenantID uuid.UUID, filter model.TokenExchangeEventFilter) ([]*model.TokenExchangeEvent, error) {
+	ctx, span := tracer.Start(ctx, "TokenExchangeRepository.ListEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("token_exchange.outcome", string(filter.Outcome)),
+	)
+
+	query := `SELECT ` + tokenExchangeEventColumns + `
+		FROM token_exchange_events
+		WHERE tenant_id = $1
+			AND ($2 = '' OR subject = $2)
+			AND ($3 = '' OR actor = $3)
+			AND ($4 = '' OR audience = $4)
+			AND ($5 = '' OR outcome = $5)
+			AND ($6::TIMESTAMPTZ IS NULL OR occurred_at >= $6)
+		ORDER BY occurred_at DESC
+		LIMIT $7
+	`
+
+	var events []*model.TokenExchangeEvent
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query,
+			tenantID, filter.Subject, filter.Actor, filter.Audience, string(filter.Outcome), filter.Since, filter.Limit,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar registo de trocas de tokens: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				event   model.TokenExchangeEvent
+				outcome string
+			)
+			if err := rows.Scan(
+				&event.ID, &event.TenantID, &event.Subject, &event.Actor, &event.ActorChain, &event.Audience,
+				&event.PolicyID, &event.Scopes, &outcome, &event.Reason, &event.TokenID, &event.ExpiresAt,
+				&event.IPAddress, &event.OccurredAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler registo de trocas de tokens: %w", err)
+			}
+			event.Outcome = model.TokenExchangeOutcome(outcome)
+			events = append(events, &event)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// scanTokenExchangePolicy lê as colunas de tokenExchangePolicyColumns
+func scanTokenExchangePolicy(row pgx.Row) (*model.TokenExchangePolicy, error) {
+	var policy model.TokenExchangePolicy
+	err := row.Scan(
+		&policy.ID, &policy.TenantID, &policy.Audience, &policy.Description, &policy.AllowedActors,
+		&policy.AllowedScopes, &policy.MaxTTLSeconds, &policy.MaxDelegationDepth, &policy.Enabled,
+		&policy.CreatedBy, &policy.UpdatedBy, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler política de troca de tokens: %w", err)
+	}
+	return &policy, nil
+}
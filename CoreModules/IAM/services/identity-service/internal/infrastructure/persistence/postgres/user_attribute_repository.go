@@ -0,0 +1,365 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas lidas pelo repositório dos esquemas dos atributos
+const userAttributeSchemaColumns = `
+	id, tenant_id, name, COALESCE(display_name, ''), COALESCE(description, ''), type, required,
+	enum_values, COALESCE(pattern, ''), max_length, min_value, max_value, COALESCE(claim_name, ''),
+	COALESCE(created_by, '00000000-0000-0000-0000-000000000000'::UUID),
+	COALESCE(updated_by, '00000000-0000-0000-0000-000000000000'::UUID), created_at, updated_at
+`
+
+// UserAttributeRepository implementa a interface repository.UserAttributeRepository usando PostgreSQL
+type UserAttributeRepository struct {
+	db *DB
+}
+
+// NewUserAttributeRepository cria uma nova instância do UserAttributeRepository
+func NewUserAttributeRepository(db *DB) *UserAttributeRepository {
+	return &UserAttributeRepository{db: db}
+}
+
+// ListSchemas recupera os esquemas dos atributos do tenant, ordenados pelo nome
+func (r *UserAttributeRepository) ListSchemas(ctx context.Context, tenantID uuid.UUID) ([]*model.UserAttributeSchema, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.ListSchemas")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `SELECT ` + userAttributeSchemaColumns + `
+		FROM user_attribute_schemas
+		WHERE tenant_id = $1
+		ORDER BY name
+	`
+
+	var schemas []*model.UserAttributeSchema
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar esquemas de atributos: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			schema, err := scanUserAttributeSchema(rows)
+			if err != nil {
+				return err
+			}
+			schemas = append(schemas, schema)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+// GetSchema recupera um esquema do tenant
+func (r *UserAttributeRepository) GetSchema(ctx context.Context, tenantID, schemaID uuid.UUID) (*model.UserAttributeSchema, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.GetSchema")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user_attribute.schema_id", schemaID.String()),
+	)
+
+	query := `SELECT ` + userAttributeSchemaColumns + `
+		FROM user_attribute_schemas
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var schema *model.UserAttributeSchema
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		found, err := scanUserAttributeSchema(tx.QueryRow(ctx, query, tenantID, schemaID))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserAttributeSchemaNotFound
+		}
+		if err != nil {
+			return err
+		}
+		schema = found
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// CreateSchema grava um novo esquema
+func (r *UserAttributeRepository) CreateSchema(ctx context.Context, schema *model.UserAttributeSchema) error {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.CreateSchema")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", schema.TenantID.String()),
+		attribute.String("user_attribute.name", schema.Name),
+	)
+
+	query := `
+		INSERT INTO user_attribute_schemas (
+			id, tenant_id, name, display_name, description, type, required, enum_values, pattern,
+			max_length, min_value, max_value, claim_name, created_by, updated_by, created_at, updated_at
+		) VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, COALESCE($8::TEXT[], '{}'), NULLIF($9, ''),
+			$10, $11, $12, NULLIF($13, ''), $14, $15, $16, $17)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			schema.ID, schema.TenantID, schema.Name, schema.DisplayName, schema.Description, string(schema.Type),
+			schema.Required, schema.EnumValues, schema.Pattern, schema.MaxLength, schema.MinValue, schema.MaxValue,
+			schema.ClaimName, schema.CreatedBy, schema.UpdatedBy, schema.CreatedAt, schema.UpdatedAt,
+		)
+		if err != nil {
+			return userAttributeSchemaWriteError(err, "erro ao inserir esquema de atributo")
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateSchema altera as restrições, a obrigatoriedade e a claim de um esquema
+func (r *UserAttributeRepository) UpdateSchema(ctx context.Context, schema *model.UserAttributeSchema) error {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.UpdateSchema")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", schema.TenantID.String()),
+		attribute.String("user_attribute.schema_id", schema.ID.String()),
+	)
+
+	query := `
+		UPDATE user_attribute_schemas SET
+			display_name = NULLIF($3, ''),
+			description = NULLIF($4, ''),
+			required = $5,
+			enum_values = COALESCE($6::TEXT[], '{}'),
+			pattern = NULLIF($7, ''),
+			max_length = $8,
+			min_value = $9,
+			max_value = $10,
+			claim_name = NULLIF($11, ''),
+			updated_by = $12,
+			updated_at = $13
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query,
+			schema.TenantID, schema.ID, schema.DisplayName, schema.Description, schema.Required, schema.EnumValues,
+			schema.Pattern, schema.MaxLength, schema.MinValue, schema.MaxValue, schema.ClaimName,
+			schema.UpdatedBy, schema.UpdatedAt,
+		)
+		if err != nil {
+			return userAttributeSchemaWriteError(err, "erro ao atualizar esquema de atributo")
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrUserAttributeSchemaNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSchema remove o esquema e o atributo de todos os usuários do tenant, atomicamente
+func (r *UserAttributeRepository) DeleteSchema(ctx context.Context, tenantID, schemaID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.DeleteSchema")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user_attribute.schema_id", schemaID.String()),
+	)
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var name string
+		err := tx.QueryRow(ctx, `
+			DELETE FROM user_attribute_schemas WHERE tenant_id = $1 AND id = $2
+			RETURNING name
+		`, tenantID, schemaID).Scan(&name)
+		if err == pgx.ErrNoRows {
+			return model.ErrUserAttributeSchemaNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao remover esquema de atributo: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE user_attributes SET attribute_values = attribute_values - $2
+			WHERE tenant_id = $1 AND attribute_values ? $2
+		`, tenantID, name)
+		if err != nil {
+			return fmt.Errorf("erro ao remover atributo dos usuários: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetUserAttributes recupera os atributos do usuário; sem atributos gravados, os valores ficam vazios
+func (r *UserAttributeRepository) GetUserAttributes(ctx context.Context, tenantID, userID uuid.UUID) (*model.UserAttributes, error) {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.GetUserAttributes")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	query := `
+		SELECT a.attribute_values, a.updated_by, a.updated_at
+		FROM users u
+		LEFT JOIN user_attributes a ON a.user_id = u.id AND a.tenant_id = u.tenant_id
+		WHERE u.tenant_id = $1 AND u.id = $2 AND u.deleted_at IS NULL
+	`
+
+	attributes := &model.UserAttributes{TenantID: tenantID, UserID: userID}
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var values []byte
+		err := tx.QueryRow(ctx, query, tenantID, userID).Scan(&values, &attributes.UpdatedBy, &attributes.UpdatedAt)
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar atributos do usuário: %w", err)
+		}
+		if len(values) > 0 {
+			if err := json.Unmarshal(values, &attributes.Values); err != nil {
+				return fmt.Errorf("erro ao ler atributos do usuário: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// SaveUserAttributes grava os atributos do usuário, substituindo os anteriores
+func (r *UserAttributeRepository) SaveUserAttributes(ctx context.Context, attributes *model.UserAttributes) error {
+	ctx, span := tracer.Start(ctx, "UserAttributeRepository.SaveUserAttributes")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", attributes.TenantID.String()),
+		attribute.String("user.id", attributes.UserID.String()),
+	)
+
+	values, err := json.Marshal(attributes.Values)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar atributos do usuário: %w", err)
+	}
+
+	// O usuário tem de existir no tenant; a chave estrangeira não verifica o tenant nem a remoção lógica
+	query := `
+		INSERT INTO user_attributes (user_id, tenant_id, attribute_values, updated_by, updated_at)
+		SELECT u.id, u.tenant_id, $3, $4, $5
+		FROM users u
+		WHERE u.tenant_id = $1 AND u.id = $2 AND u.deleted_at IS NULL
+		ON CONFLICT (user_id) DO UPDATE SET
+			attribute_values = EXCLUDED.attribute_values,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	err = r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query,
+			attributes.TenantID, attributes.UserID, values, attributes.UpdatedBy, attributes.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar atributos do usuário: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrUserNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// scanUserAttributeSchema lê um esquema de atributo
+func scanUserAttributeSchema(row pgx.Row) (*model.UserAttributeSchema, error) {
+	var schema model.UserAttributeSchema
+	var attributeType string
+	err := row.Scan(
+		&schema.ID, &schema.TenantID, &schema.Name, &schema.DisplayName, &schema.Description, &attributeType,
+		&schema.Required, &schema.EnumValues, &schema.Pattern, &schema.MaxLength, &schema.MinValue,
+		&schema.MaxValue, &schema.ClaimName, &schema.CreatedBy, &schema.UpdatedBy, &schema.CreatedAt, &schema.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler esquema de atributo: %w", err)
+	}
+	schema.Type = model.UserAttributeType(attributeType)
+	if len(schema.EnumValues) == 0 {
+		schema.EnumValues = nil
+	}
+	return &schema, nil
+}
+
+// userAttributeSchemaWriteError converte as violações de unicidade do nome e da claim
+func userAttributeSchemaWriteError(err error, message string) error {
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == pgerrcode.UniqueViolation {
+		return model.ErrUserAttributeSchemaConflict
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
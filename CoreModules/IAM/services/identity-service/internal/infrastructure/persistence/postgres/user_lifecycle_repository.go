@@ -0,0 +1,397 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Colunas do usuário lidas pelo repositório de ciclo de vida
+const userLifecycleColumns = `
+	id, tenant_id, username, email, email_verified, first_name, last_name,
+	status, COALESCE(status_reason, ''), status_changed_at, invitation_expires_at,
+	login_count, last_login_at, created_at, updated_at, deleted_at
+`
+
+// UserLifecycleRepository implementa a interface repository.UserLifecycleRepository usando PostgreSQL
+type UserLifecycleRepository struct {
+	db *DB
+}
+
+// NewUserLifecycleRepository cria uma nova instância do UserLifecycleRepository
+func NewUserLifecycleRepository(db *DB) *UserLifecycleRepository {
+	return &UserLifecycleRepository{db: db}
+}
+
+// GetUser recupera o usuário com os dados de ciclo de vida
+func (r *UserLifecycleRepository) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*model.User, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.GetUser")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND id = $2
+	`
+
+	var user *model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		user, err = scanLifecycleUser(tx.QueryRow(ctx, query, tenantID, userID))
+		if err == pgx.ErrNoRows {
+			return model.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuário: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SaveTransition grava o novo estado do usuário e o registo da transição numa transação
+func (r *UserLifecycleRepository) SaveTransition(ctx context.Context, user *model.User, transition *model.UserLifecycleTransition) error {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.SaveTransition")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", user.ID.String()),
+		attribute.String("tenant.id", user.TenantID.String()),
+		attribute.String("transition.from", string(transition.FromStatus)),
+		attribute.String("transition.to", string(transition.ToStatus)),
+	)
+
+	// A condição sobre o estado de origem impede transições concorrentes sobre o mesmo usuário
+	updateQuery := `
+		UPDATE users
+		SET status = $3, status_reason = NULLIF($4, ''), status_changed_at = $5,
+			invitation_expires_at = $6, updated_at = $7, deleted_at = $8
+		WHERE tenant_id = $1 AND id = $2 AND status = $9
+	`
+
+	insertQuery := `
+		INSERT INTO user_lifecycle_transitions (
+			id, tenant_id, user_id, from_status, to_status,
+			reason, actor_id, occurred_at
+		) VALUES (
+			$1, $2, $3, $4, $5,
+			NULLIF($6, ''), $7, $8
+		)
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, updateQuery,
+			user.TenantID, user.ID, string(user.Status), user.StatusReason, user.StatusChangedAt,
+			user.InvitationExpiresAt, user.UpdatedAt, user.DeletedAt, string(transition.FromStatus),
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar estado do usuário: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: usuário %s não está em %s", model.ErrUserLifecycleConflict, user.ID, transition.FromStatus)
+		}
+
+		_, err = tx.Exec(ctx, insertQuery,
+			transition.ID, transition.TenantID, transition.UserID, string(transition.FromStatus), string(transition.ToStatus),
+			transition.Reason, transition.ActorID, transition.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir transição do usuário: %w", err)
+		}
+
+		// Contas suspensas ou desprovisionadas perdem as sessões ativas
+		if transition.ToStatus == model.UserStatusSuspended || transition.ToStatus == model.UserStatusDeprovisioned {
+			_, err = tx.Exec(ctx, `DELETE FROM user_sessions WHERE tenant_id = $1 AND user_id = $2`, user.TenantID, user.ID)
+			if err != nil {
+				return fmt.Errorf("erro ao revogar sessões do usuário: %w", err)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListTransitions recupera as transições do usuário em ordem cronológica
+func (r *UserLifecycleRepository) ListTransitions(ctx context.Context, tenantID, userID uuid.UUID) ([]*model.UserLifecycleTransition, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.ListTransitions")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", userID.String()),
+		attribute.String("tenant.id", tenantID.String()),
+	)
+
+	query := `
+		SELECT
+			id, tenant_id, user_id, from_status, to_status,
+			COALESCE(reason, ''), actor_id, occurred_at
+		FROM user_lifecycle_transitions
+		WHERE tenant_id = $1 AND user_id = $2
+		ORDER BY occurred_at ASC
+	`
+
+	var transitions []*model.UserLifecycleTransition
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar transições do usuário: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				transition model.UserLifecycleTransition
+				from, to   string
+			)
+			if err := rows.Scan(
+				&transition.ID, &transition.TenantID, &transition.UserID, &from, &to,
+				&transition.Reason, &transition.ActorID, &transition.OccurredAt,
+			); err != nil {
+				return fmt.Errorf("erro ao ler transição do usuário: %w", err)
+			}
+
+			transition.FromStatus = model.UserStatus(from)
+			transition.ToStatus = model.UserStatus(to)
+			transitions = append(transitions, &transition)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+// FindInactiveUsers recupera usuários ativos sem login nem mudança de estado desde o instante indicado
+func (r *UserLifecycleRepository) FindInactiveUsers(ctx context.Context, tenantID uuid.UUID, inactiveSince time.Time, limit int) ([]*model.User, error) {
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND status = 'active' AND deleted_at IS NULL
+			AND GREATEST(created_at, COALESCE(last_login_at, created_at), COALESCE(status_changed_at, created_at)) < $2
+		ORDER BY created_at ASC
+		LIMIT $3
+	`
+
+	return r.findUsers(ctx, "UserLifecycleRepository.FindInactiveUsers", query, tenantID, inactiveSince, limit)
+}
+
+// FindSuspendedUsers recupera usuários suspensos desde antes do instante indicado
+func (r *UserLifecycleRepository) FindSuspendedUsers(ctx context.Context, tenantID uuid.UUID, suspendedBefore time.Time, limit int) ([]*model.User, error) {
+	query := `SELECT ` + userLifecycleColumns + `
+		FROM users
+		WHERE tenant_id = $1 AND status = 'suspended' AND deleted_at IS NULL
+			AND COALESCE(status_changed_at, updated_at) < $2
+		ORDER BY status_changed_at ASC NULLS FIRST
+		LIMIT $3
+	`
+
+	return r.findUsers(ctx, "UserLifecycleRepository.FindSuspendedUsers", query, tenantID, suspendedBefore, limit)
+}
+
+// GetPolicy recupera a política de ciclo de vida do tenant
+func (r *UserLifecycleRepository) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*model.UserLifecyclePolicy, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.GetPolicy")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	query := `
+		SELECT
+			tenant_id, suspend_after_inactivity_days, deprovision_after_suspension_days,
+			invitation_ttl_days, updated_at, updated_by
+		FROM user_lifecycle_policies
+		WHERE tenant_id = $1
+	`
+
+	var policy model.UserLifecyclePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, tenantID).Scan(
+			&policy.TenantID, &policy.SuspendAfterInactivityDays, &policy.DeprovisionAfterSuspensionDays,
+			&policy.InvitationTTLDays, &policy.UpdatedAt, &policy.UpdatedBy,
+		)
+		if err == pgx.ErrNoRows {
+			return model.ErrUserLifecyclePolicyNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao consultar política de ciclo de vida: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy cria ou substitui a política de ciclo de vida do tenant
+func (r *UserLifecycleRepository) SavePolicy(ctx context.Context, policy *model.UserLifecyclePolicy) error {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.SavePolicy")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tenant.id", policy.TenantID.String()))
+
+	query := `
+		INSERT INTO user_lifecycle_policies (
+			tenant_id, suspend_after_inactivity_days, deprovision_after_suspension_days,
+			invitation_ttl_days, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			suspend_after_inactivity_days = EXCLUDED.suspend_after_inactivity_days,
+			deprovision_after_suspension_days = EXCLUDED.deprovision_after_suspension_days,
+			invitation_ttl_days = EXCLUDED.invitation_ttl_days,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+	`
+
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			policy.TenantID, policy.SuspendAfterInactivityDays, policy.DeprovisionAfterSuspensionDays,
+			policy.InvitationTTLDays, policy.UpdatedAt, policy.UpdatedBy,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar política de ciclo de vida: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListPolicies recupera as políticas de todos os tenants
+func (r *UserLifecycleRepository) ListPolicies(ctx context.Context) ([]*model.UserLifecyclePolicy, error) {
+	ctx, span := tracer.Start(ctx, "UserLifecycleRepository.ListPolicies")
+	defer span.End()
+
+	query := `
+		SELECT
+			tenant_id, suspend_after_inactivity_days, deprovision_after_suspension_days,
+			invitation_ttl_days, updated_at, updated_by
+		FROM user_lifecycle_policies
+		ORDER BY tenant_id
+	`
+
+	var policies []*model.UserLifecyclePolicy
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar políticas de ciclo de vida: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var policy model.UserLifecyclePolicy
+			if err := rows.Scan(
+				&policy.TenantID, &policy.SuspendAfterInactivityDays, &policy.DeprovisionAfterSuspensionDays,
+				&policy.InvitationTTLDays, &policy.UpdatedAt, &policy.UpdatedBy,
+			); err != nil {
+				return fmt.Errorf("erro ao ler política de ciclo de vida: %w", err)
+			}
+			policies = append(policies, &policy)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// findUsers executa uma consulta de candidatos às transições automáticas
+func (r *UserLifecycleRepository) findUsers(ctx context.Context, spanName, query string, tenantID uuid.UUID, cutoff time.Time, limit int) ([]*model.User, error) {
+	ctx, span := tracer.Start(ctx, spanName)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Int("limit", limit),
+	)
+
+	var users []*model.User
+	err := r.db.InTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, tenantID, cutoff, limit)
+		if err != nil {
+			return fmt.Errorf("erro ao consultar usuários: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			user, err := scanLifecycleUser(rows)
+			if err != nil {
+				return fmt.Errorf("erro ao ler usuário: %w", err)
+			}
+			users = append(users, user)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// scanLifecycleUser lê as colunas de userLifecycleColumns
+func scanLifecycleUser(row pgx.Row) (*model.User, error) {
+	var (
+		user   model.User
+		status string
+	)
+	err := row.Scan(
+		&user.ID, &user.TenantID, &user.Username, &user.Email, &user.EmailVerified, &user.FirstName, &user.LastName,
+		&status, &user.StatusReason, &user.StatusChangedAt, &user.InvitationExpiresAt,
+		&user.LoginCount, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Status = model.UserStatus(status)
+	user.Metadata = make(map[string]interface{})
+	return &user, nil
+}
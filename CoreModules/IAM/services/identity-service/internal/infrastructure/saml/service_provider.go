@@ -0,0 +1,316 @@
+package saml
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	gosaml "github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Config representa a configuração do fornecedor de serviço SAML
+type Config struct {
+	// BaseURL é o URL público da API (por exemplo https://iam.innovabiz.com/api/v1),
+	// usado para derivar o entity ID e o ACS de cada fornecedor de identidade
+	BaseURL string
+	// KeyFile e CertificateFile contêm a chave RSA e o certificado (PEM) do SP
+	KeyFile         string
+	CertificateFile string
+	// SignAuthnRequests assina os pedidos de autenticação com RSA-SHA256
+	SignAuthnRequests bool
+}
+
+// ServiceProvider implementa application.SAMLServiceProvider com github.com/crewjam/saml
+// Cada fornecedor de identidade tem o seu próprio entity ID e ACS, derivados do seu ID
+type ServiceProvider struct {
+	baseURL      *url.URL
+	key          *rsa.PrivateKey
+	certificate  *x509.Certificate
+	signRequests bool
+}
+
+// NewServiceProvider cria o fornecedor de serviço a partir da chave e do certificado configurados
+func NewServiceProvider(config Config) (*ServiceProvider, error) {
+	keyPair, err := tls.LoadX509KeyPair(config.CertificateFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar chave e certificado do SP SAML: %w", err)
+	}
+	key, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("a chave do SP SAML deve ser RSA")
+	}
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler certificado do SP SAML: %w", err)
+	}
+
+	return NewServiceProviderWithKey(config.BaseURL, key, certificate, config.SignAuthnRequests)
+}
+
+// NewServiceProviderWithKey cria o fornecedor de serviço com a chave e o certificado já carregados
+func NewServiceProviderWithKey(baseURL string, key *rsa.PrivateKey, certificate *x509.Certificate, signRequests bool) (*ServiceProvider, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("URL público inválido para o SP SAML: %q", baseURL)
+	}
+	return &ServiceProvider{
+		baseURL:      parsed,
+		key:          key,
+		certificate:  certificate,
+		signRequests: signRequests,
+	}, nil
+}
+
+// Metadata gera os metadados do SP para o fornecedor de identidade indicado
+func (p *ServiceProvider) Metadata(provider *model.SAMLIdentityProvider) ([]byte, error) {
+	sp, err := p.serviceProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar metadados do SP: %w", err)
+	}
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+// AuthnRequest gera um pedido de autenticação com o binding HTTP-Redirect
+func (p *ServiceProvider) AuthnRequest(provider *model.SAMLIdentityProvider, relayState string) (string, string, error) {
+	sp, err := p.serviceProvider(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	request, err := sp.MakeAuthenticationRequest(provider.SSOURL, gosaml.HTTPRedirectBinding, gosaml.HTTPPostBinding)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao gerar pedido de autenticação: %w", err)
+	}
+	redirectURL, err := request.Redirect(url.QueryEscape(relayState), sp)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao gerar URL de redirecionamento: %w", err)
+	}
+	return request.ID, redirectURL.String(), nil
+}
+
+// ParseResponse valida a resposta do IdP e extrai a asserção
+// A biblioteca verifica a assinatura com os certificados do IdP, o emissor, a audiência,
+// o destino, o intervalo de validade e o InResponseTo do pedido indicado
+func (p *ServiceProvider) ParseResponse(provider *model.SAMLIdentityProvider, samlResponse, requestID string) (*model.SAMLAssertion, error) {
+	sp, err := p.serviceProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(samlResponse))
+	if err != nil {
+		return nil, fmt.Errorf("%w: codificação base64 inválida", model.ErrInvalidSAMLResponse)
+	}
+
+	assertion, err := sp.ParseXMLResponse(decoded, []string{requestID})
+	if err != nil {
+		var invalid *gosaml.InvalidResponseError
+		if errors.As(err, &invalid) && invalid.PrivateErr != nil {
+			err = invalid.PrivateErr
+		}
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidSAMLResponse, err)
+	}
+
+	return toModelAssertion(assertion), nil
+}
+
+// ParseIdPMetadata extrai os dados do IdP dos seus metadados
+// Aceita um EntityDescriptor ou um EntitiesDescriptor, usando a primeira entidade com IDPSSODescriptor
+func (p *ServiceProvider) ParseIdPMetadata(metadata []byte) (*model.SAMLIdentityProvider, error) {
+	entity, err := parseEntityDescriptor(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &model.SAMLIdentityProvider{EntityID: entity.EntityID}
+	for _, descriptor := range entity.IDPSSODescriptors {
+		for _, service := range descriptor.SingleSignOnServices {
+			if service.Binding == gosaml.HTTPRedirectBinding && provider.SSOURL == "" {
+				provider.SSOURL = service.Location
+			}
+		}
+		for _, key := range descriptor.KeyDescriptors {
+			if key.Use != "" && key.Use != "signing" {
+				continue
+			}
+			for _, certificate := range key.KeyInfo.X509Data.X509Certificates {
+				encoded, err := certificateToPEM(certificate.Data)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", model.ErrInvalidSAMLMetadata, err)
+				}
+				provider.SigningCertificates = append(provider.SigningCertificates, encoded)
+			}
+		}
+		if len(descriptor.NameIDFormats) > 0 && provider.NameIDFormat == "" {
+			provider.NameIDFormat = string(descriptor.NameIDFormats[0])
+		}
+	}
+
+	switch {
+	case provider.EntityID == "":
+		return nil, fmt.Errorf("%w: entity ID ausente", model.ErrInvalidSAMLMetadata)
+	case provider.SSOURL == "":
+		return nil, fmt.Errorf("%w: o IdP não publica um serviço de SSO com o binding HTTP-Redirect", model.ErrInvalidSAMLMetadata)
+	case len(provider.SigningCertificates) == 0:
+		return nil, fmt.Errorf("%w: o IdP não publica certificados de assinatura", model.ErrInvalidSAMLMetadata)
+	}
+	return provider, nil
+}
+
+// serviceProvider configura o SP da biblioteca para o fornecedor de identidade
+func (p *ServiceProvider) serviceProvider(provider *model.SAMLIdentityProvider) (*gosaml.ServiceProvider, error) {
+	idpMetadata, err := idpEntityDescriptor(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	base := p.baseURL.String() + "/saml/" + provider.ID.String()
+	metadataURL, _ := url.Parse(base + "/metadata")
+	acsURL, _ := url.Parse(base + "/acs")
+
+	nameIDFormat := gosaml.UnspecifiedNameIDFormat
+	if provider.NameIDFormat != "" {
+		nameIDFormat = gosaml.NameIDFormat(provider.NameIDFormat)
+	}
+
+	sp := &gosaml.ServiceProvider{
+		EntityID:          metadataURL.String(),
+		Key:               p.key,
+		Certificate:       p.certificate,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: nameIDFormat,
+		AllowIDPInitiated: false,
+	}
+	if p.signRequests {
+		sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	}
+	return sp, nil
+}
+
+// idpEntityDescriptor constrói os metadados do IdP a partir do registo do tenant
+func idpEntityDescriptor(provider *model.SAMLIdentityProvider) (*gosaml.EntityDescriptor, error) {
+	keys := make([]gosaml.KeyDescriptor, 0, len(provider.SigningCertificates))
+	for _, encoded := range provider.SigningCertificates {
+		der, err := certificateDER(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidSAMLProvider, err)
+		}
+		keys = append(keys, gosaml.KeyDescriptor{
+			Use: "signing",
+			KeyInfo: gosaml.KeyInfo{X509Data: gosaml.X509Data{
+				X509Certificates: []gosaml.X509Certificate{{Data: base64.StdEncoding.EncodeToString(der)}},
+			}},
+		})
+	}
+
+	return &gosaml.EntityDescriptor{
+		EntityID: provider.EntityID,
+		IDPSSODescriptors: []gosaml.IDPSSODescriptor{{
+			SSODescriptor: gosaml.SSODescriptor{
+				RoleDescriptor: gosaml.RoleDescriptor{
+					ProtocolSupportEnumeration: "urn:oasis:names:tc:SAML:2.0:protocol",
+					KeyDescriptors:             keys,
+				},
+			},
+			SingleSignOnServices: []gosaml.Endpoint{{Binding: gosaml.HTTPRedirectBinding, Location: provider.SSOURL}},
+		}},
+	}, nil
+}
+
+// parseEntityDescriptor lê os metadados de um IdP
+func parseEntityDescriptor(metadata []byte) (*gosaml.EntityDescriptor, error) {
+	var entity gosaml.EntityDescriptor
+	if err := xml.Unmarshal(metadata, &entity); err == nil {
+		if len(entity.IDPSSODescriptors) == 0 {
+			return nil, fmt.Errorf("%w: a entidade não é um fornecedor de identidade", model.ErrInvalidSAMLMetadata)
+		}
+		return &entity, nil
+	}
+
+	var entities gosaml.EntitiesDescriptor
+	if err := xml.Unmarshal(metadata, &entities); err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidSAMLMetadata, err)
+	}
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: nenhuma entidade é um fornecedor de identidade", model.ErrInvalidSAMLMetadata)
+}
+
+// toModelAssertion converte a asserção validada; os atributos são indexados pelo nome e pelo nome amigável
+func toModelAssertion(assertion *gosaml.Assertion) *model.SAMLAssertion {
+	result := &model.SAMLAssertion{Attributes: make(map[string][]string)}
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		result.NameID = strings.TrimSpace(assertion.Subject.NameID.Value)
+		result.NameIDFormat = assertion.Subject.NameID.Format
+	}
+	for _, statement := range assertion.AuthnStatements {
+		if statement.SessionIndex != "" {
+			result.SessionIndex = statement.SessionIndex
+			break
+		}
+	}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			values := make([]string, 0, len(attr.Values))
+			for _, value := range attr.Values {
+				values = append(values, value.Value)
+			}
+			for _, name := range []string{attr.Name, attr.FriendlyName} {
+				if name != "" {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return result
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// certificateDER aceita um certificado em PEM ou em base64 (como publicado nos metadados)
+func certificateDER(encoded string) ([]byte, error) {
+	der := []byte(nil)
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(encoded, ""))
+		if err != nil {
+			return nil, fmt.Errorf("certificado com codificação inválida: %v", err)
+		}
+		der = decoded
+	}
+	if _, err := x509.ParseCertificate(der); err != nil {
+		return nil, fmt.Errorf("certificado X.509 inválido: %v", err)
+	}
+	return der, nil
+}
+
+// certificateToPEM converte um certificado dos metadados para PEM
+func certificateToPEM(encoded string) (string, error) {
+	der, err := certificateDER(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,13 +15,42 @@ import (
 
 	"innovabiz/iam/identity-service/internal/application"
 	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
 )
 
 // RoleHandler trata as requisições HTTP relacionadas a funções
 type RoleHandler struct {
-	roleService application.RoleService
-	logger      zerolog.Logger
-	tracer      trace.Tracer
+	roleService               application.RoleService
+	impactService             application.ImpactAnalysisService
+	historyService            application.RoleHistoryService
+	accessRequestService      application.AccessRequestService
+	templateService           application.RoleTemplateService
+	samlService               application.SAMLFederationService
+	anomalyService            application.AuditAnomalyService
+	exportService             application.TenantExportService
+	decisionService           application.PermissionDecisionAuditService
+	miningService             application.RoleMiningService
+	passwordlessService       application.PasswordlessService
+	networkPolicyService      application.NetworkPolicyService
+	sessionPolicyService      application.SessionPolicyService
+	roleMetadataPolicyService application.RoleMetadataPolicyService
+	emergencyAccessService    application.EmergencyAccessService
+	accountLinkService        application.AccountLinkService
+	legalConsentService       application.LegalConsentService
+	tokenExchangeService      application.TokenExchangeService
+	auditLogService           application.AuditLogService
+	serviceAccountService     application.ServiceAccountService
+	recertificationService    application.RecertificationService
+	userAttributeService      application.UserAttributeService
+	loginNotificationService  application.LoginNotificationService
+	permissionBoundaryService application.PermissionBoundaryService
+	bulkUserJobService        application.BulkUserJobService
+	tenantBrandingService     application.TenantBrandingService
+	deviceService             application.DeviceService
+	trustedProxies            []*net.IPNet
+	logger                    zerolog.Logger
+	tracer                    trace.Tracer
 }
 
 // NewRoleHandler cria uma nova instância do RoleHandler
@@ -68,15 +98,235 @@ func (h *RoleHandler) RegisterRoutes(router *mux.Router) {
 	// Operações Avançadas
 	router.HandleFunc("/roles/{id}/clone", h.CloneRole).Methods(http.MethodPost)
 	router.HandleFunc("/system-roles/sync", h.SyncSystemRoles).Methods(http.MethodPost)
+	
+	// Análise de impacto de alterações propostas
+	router.HandleFunc("/roles/{id}/impact-analysis", h.AnalyzeImpact).Methods(http.MethodPost)
+	
+	// Histórico imutável de funções
+	router.HandleFunc("/roles/{id}/history", h.GetRoleHistory).Methods(http.MethodGet)
+	router.HandleFunc("/roles/{id}/history/at", h.GetRoleAt).Methods(http.MethodGet)
+	router.HandleFunc("/roles/{id}/history/diff", h.DiffRole).Methods(http.MethodGet)
+	
+	// Pedidos de acesso em autoatendimento
+	router.HandleFunc("/access-requests", h.SubmitAccessRequest).Methods(http.MethodPost)
+	router.HandleFunc("/access-requests", h.ListAccessRequests).Methods(http.MethodGet)
+	router.HandleFunc("/access-requests/sla", h.GetAccessRequestSLA).Methods(http.MethodGet)
+	router.HandleFunc("/access-requests/{id}", h.GetAccessRequest).Methods(http.MethodGet)
+	router.HandleFunc("/access-requests/{id}/approve", h.ApproveAccessRequest).Methods(http.MethodPost)
+	router.HandleFunc("/access-requests/{id}/reject", h.RejectAccessRequest).Methods(http.MethodPost)
+	router.HandleFunc("/access-requests/{id}/cancel", h.CancelAccessRequest).Methods(http.MethodPost)
+	router.HandleFunc("/access-approvers", h.ListAccessApprovers).Methods(http.MethodGet)
+	router.HandleFunc("/access-approvers", h.AddAccessApprover).Methods(http.MethodPost)
+	router.HandleFunc("/access-approvers/{id}", h.RemoveAccessApprover).Methods(http.MethodDelete)
+	
+	// Biblioteca de modelos de função partilhada entre tenants
+	router.HandleFunc("/role-templates", h.CreateRoleTemplate).Methods(http.MethodPost)
+	router.HandleFunc("/role-templates", h.ListRoleTemplates).Methods(http.MethodGet)
+	router.HandleFunc("/role-templates/instances", h.ListRoleTemplateInstances).Methods(http.MethodGet)
+	router.HandleFunc("/role-templates/drift", h.DetectTenantRoleTemplateDrift).Methods(http.MethodGet)
+	router.HandleFunc("/role-templates/{id}", h.GetRoleTemplate).Methods(http.MethodGet)
+	router.HandleFunc("/role-templates/{id}", h.UpdateRoleTemplate).Methods(http.MethodPut)
+	router.HandleFunc("/role-templates/{id}/instantiate", h.InstantiateRoleTemplate).Methods(http.MethodPost)
+	router.HandleFunc("/roles/{id}/template-drift", h.GetRoleTemplateDrift).Methods(http.MethodGet)
+	
+	// Federação com fornecedores de identidade SAML 2.0
+	router.HandleFunc("/saml-providers", h.RegisterSAMLProvider).Methods(http.MethodPost)
+	router.HandleFunc("/saml-providers", h.ListSAMLProviders).Methods(http.MethodGet)
+	router.HandleFunc("/saml-providers/{id}", h.GetSAMLProvider).Methods(http.MethodGet)
+	router.HandleFunc("/saml-providers/{id}", h.UpdateSAMLProvider).Methods(http.MethodPut)
+	router.HandleFunc("/saml/{id}/metadata", h.GetSAMLServiceProviderMetadata).Methods(http.MethodGet)
+	router.HandleFunc("/saml/{id}/login", h.StartSAMLLogin).Methods(http.MethodGet)
+	router.HandleFunc("/saml/{id}/acs", h.ConsumeSAMLAssertion).Methods(http.MethodPost)
+
+	// Triagem de incidentes de segurança detetados no fluxo de auditoria
+	router.HandleFunc("/security-incidents", h.ListSecurityIncidents).Methods(http.MethodGet)
+	router.HandleFunc("/security-incidents/{id}", h.GetSecurityIncident).Methods(http.MethodGet)
+	router.HandleFunc("/security-incidents/{id}/acknowledge", h.AcknowledgeSecurityIncident).Methods(http.MethodPost)
+	router.HandleFunc("/security-incidents/{id}/resolve", h.ResolveSecurityIncident).Methods(http.MethodPost)
+	router.HandleFunc("/security-incidents/{id}/dismiss", h.DismissSecurityIncident).Methods(http.MethodPost)
+
+	// Exportação dos dados do tenant na saída da plataforma
+	router.HandleFunc("/tenant-exports", h.StartTenantExport).Methods(http.MethodPost)
+	router.HandleFunc("/tenant-exports", h.ListTenantExports).Methods(http.MethodGet)
+	router.HandleFunc("/tenant-exports/signing-key", h.GetTenantExportSigningKey).Methods(http.MethodGet)
+	router.HandleFunc("/tenant-exports/{id}", h.GetTenantExport).Methods(http.MethodGet)
+	router.HandleFunc("/tenant-exports/{id}/resume", h.ResumeTenantExport).Methods(http.MethodPost)
+	router.HandleFunc("/tenant-exports/{id}/files/{name}", h.DownloadTenantExportFile).Methods(http.MethodGet)
+
+	// Auditoria das decisões de autorização
+	router.HandleFunc("/permission-decisions", h.ListPermissionDecisions).Methods(http.MethodGet)
+
+	// Consulta dos registos de auditoria
+	router.HandleFunc("/audit-logs", h.SearchAuditLogs).Methods(http.MethodGet)
+	router.HandleFunc("/audit-logs/export", h.ExportAuditLogs).Methods(http.MethodGet)
+
+	// Sugestões de função mineradas das permissões diretas
+	router.HandleFunc("/role-suggestions", h.ListRoleSuggestions).Methods(http.MethodGet)
+	router.HandleFunc("/role-suggestions/mine", h.MineRoleSuggestions).Methods(http.MethodPost)
+	router.HandleFunc("/role-suggestions/{id}", h.GetRoleSuggestion).Methods(http.MethodGet)
+	router.HandleFunc("/role-suggestions/{id}/accept", h.AcceptRoleSuggestion).Methods(http.MethodPost)
+	router.HandleFunc("/role-suggestions/{id}/dismiss", h.DismissRoleSuggestion).Methods(http.MethodPost)
+
+	// Autenticação sem senha por link mágico e por código enviado por email
+	router.HandleFunc("/passwordless/settings", h.GetPasswordlessSettings).Methods(http.MethodGet)
+	router.HandleFunc("/passwordless/settings", h.UpdatePasswordlessSettings).Methods(http.MethodPut)
+	router.HandleFunc("/passwordless/start", h.StartPasswordlessLogin).Methods(http.MethodPost)
+	router.HandleFunc("/passwordless/magic-link/verify", h.VerifyPasswordlessMagicLink).Methods(http.MethodPost)
+	router.HandleFunc("/passwordless/otp/verify", h.VerifyPasswordlessOTP).Methods(http.MethodPost)
+
+	// Políticas de rede por tenant e acessos de emergência
+	// As rotas de break-glass são registadas antes de /network-policies/{id}
+	router.HandleFunc("/network-policies", h.ListNetworkPolicies).Methods(http.MethodGet)
+	router.HandleFunc("/network-policies", h.CreateNetworkPolicy).Methods(http.MethodPost)
+	router.HandleFunc("/network-policies/break-glass", h.ListBreakGlassOverrides).Methods(http.MethodGet)
+	router.HandleFunc("/network-policies/break-glass", h.ActivateBreakGlass).Methods(http.MethodPost)
+	router.HandleFunc("/network-policies/break-glass/{id}/revoke", h.RevokeBreakGlass).Methods(http.MethodPost)
+	router.HandleFunc("/network-policies/break-glass/{id}/uses", h.ListBreakGlassUses).Methods(http.MethodGet)
+	router.HandleFunc("/network-policies/{id}", h.GetNetworkPolicy).Methods(http.MethodGet)
+	router.HandleFunc("/network-policies/{id}", h.UpdateNetworkPolicy).Methods(http.MethodPut)
+	router.HandleFunc("/network-policies/{id}", h.DeleteNetworkPolicy).Methods(http.MethodDelete)
+
+	// Validade dos tokens e duração das sessões por tenant
+	router.HandleFunc("/session-policy", h.GetSessionPolicy).Methods(http.MethodGet)
+	router.HandleFunc("/session-policy", h.UpdateSessionPolicy).Methods(http.MethodPut)
+	router.HandleFunc("/session-policy", h.ResetSessionPolicy).Methods(http.MethodDelete)
+	router.HandleFunc("/session-policy/lifetimes", h.GetSessionLifetimes).Methods(http.MethodGet)
+
+	// Políticas de acesso às chaves dos metadados das funções, definidas no catálogo de permissões
+	router.HandleFunc("/role-metadata-policies", h.ListRoleMetadataPolicies).Methods(http.MethodGet)
+
+	// Contas de emergência (break-glass): ativação com controlo duplo, registo das ações e revisão
+	router.HandleFunc("/emergency-access/accounts", h.ListEmergencyAccounts).Methods(http.MethodGet)
+	router.HandleFunc("/emergency-access/accounts", h.ProvisionEmergencyAccount).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations", h.ListEmergencyActivations).Methods(http.MethodGet)
+	router.HandleFunc("/emergency-access/activations", h.RequestEmergencyActivation).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations/{id}", h.GetEmergencyActivation).Methods(http.MethodGet)
+	router.HandleFunc("/emergency-access/activations/{id}/approve", h.ApproveEmergencyActivation).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations/{id}/reject", h.RejectEmergencyActivation).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations/{id}/close", h.CloseEmergencyActivation).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations/{id}/review", h.ReviewEmergencyActivation).Methods(http.MethodPost)
+	router.HandleFunc("/emergency-access/activations/{id}/actions", h.ListEmergencyActions).Methods(http.MethodGet)
+	router.HandleFunc("/emergency-access/activations/{id}/actions/verify", h.VerifyEmergencyActions).Methods(http.MethodGet)
+
+	// Ligação de contas entre fornecedores de identidade, com nova autenticação e registo de auditoria
+	router.HandleFunc("/linked-identities", h.ListLinkedIdentities).Methods(http.MethodGet)
+	router.HandleFunc("/linked-identities/{id}/unlink", h.UnlinkIdentity).Methods(http.MethodPost)
+	router.HandleFunc("/account-links/candidates", h.ListAccountLinkCandidates).Methods(http.MethodGet)
+	router.HandleFunc("/account-links/candidates/{id}/link", h.LinkAccountCandidate).Methods(http.MethodPost)
+	router.HandleFunc("/account-links/candidates/{id}/dismiss", h.DismissAccountLinkCandidate).Methods(http.MethodPost)
+	router.HandleFunc("/account-links/events", h.ListAccountLinkEvents).Methods(http.MethodGet)
+
+	// Termos de serviço, políticas de privacidade e consentimentos versionados, com prova de aceitação
+	// A rota dos documentos pendentes é registada antes de /legal-documents/{id}
+	router.HandleFunc("/legal-documents", h.ListLegalDocuments).Methods(http.MethodGet)
+	router.HandleFunc("/legal-documents", h.PublishLegalDocument).Methods(http.MethodPost)
+	router.HandleFunc("/legal-documents/pending", h.ListPendingLegalDocuments).Methods(http.MethodGet)
+	router.HandleFunc("/legal-documents/{id}", h.GetLegalDocument).Methods(http.MethodGet)
+	router.HandleFunc("/legal-documents/{id}/accept", h.AcceptLegalDocument).Methods(http.MethodPost)
+	router.HandleFunc("/legal-documents/{id}/report", h.GetLegalAcceptanceReport).Methods(http.MethodGet)
+	router.HandleFunc("/legal-acceptances", h.ListLegalAcceptances).Methods(http.MethodGet)
+
+	// Troca de tokens para delegação entre serviços (RFC 8693), políticas por audiência e auditoria
+	router.HandleFunc("/oauth/token-exchange", h.ExchangeToken).Methods(http.MethodPost)
+	router.HandleFunc("/token-exchange/policies", h.ListTokenExchangePolicies).Methods(http.MethodGet)
+	router.HandleFunc("/token-exchange/policies", h.CreateTokenExchangePolicy).Methods(http.MethodPost)
+	router.HandleFunc("/token-exchange/policies/{id}", h.GetTokenExchangePolicy).Methods(http.MethodGet)
+	router.HandleFunc("/token-exchange/policies/{id}", h.UpdateTokenExchangePolicy).Methods(http.MethodPut)
+	router.HandleFunc("/token-exchange/policies/{id}", h.DeleteTokenExchangePolicy).Methods(http.MethodDelete)
+	router.HandleFunc("/token-exchange/events", h.ListTokenExchangeEvents).Methods(http.MethodGet)
+
+	// Contas de serviço com funções, credenciais (chave de API, mTLS, cliente OIDC) e ligação ao Kubernetes
+	// As rotas da utilização e da autenticação são registadas antes de /service-accounts/{id}
+	router.HandleFunc("/service-accounts", h.ListServiceAccounts).Methods(http.MethodGet)
+	router.HandleFunc("/service-accounts", h.CreateServiceAccount).Methods(http.MethodPost)
+	router.HandleFunc("/service-accounts/usage", h.GetServiceAccountUsage).Methods(http.MethodGet)
+	router.HandleFunc("/service-accounts/token", h.IssueServiceAccountToken).Methods(http.MethodPost)
+	router.HandleFunc("/service-accounts/{id}", h.GetServiceAccount).Methods(http.MethodGet)
+	router.HandleFunc("/service-accounts/{id}", h.UpdateServiceAccount).Methods(http.MethodPut)
+	router.HandleFunc("/service-accounts/{id}/roles/{roleId}", h.BindServiceAccountRole).Methods(http.MethodPut)
+	router.HandleFunc("/service-accounts/{id}/roles/{roleId}", h.UnbindServiceAccountRole).Methods(http.MethodDelete)
+	router.HandleFunc("/service-accounts/{id}/workload-identity", h.BindWorkloadIdentity).Methods(http.MethodPut)
+	router.HandleFunc("/service-accounts/{id}/workload-identity", h.UnbindWorkloadIdentity).Methods(http.MethodDelete)
+	router.HandleFunc("/service-accounts/{id}/credentials", h.ListServiceAccountCredentials).Methods(http.MethodGet)
+	router.HandleFunc("/service-accounts/{id}/credentials", h.IssueServiceAccountCredential).Methods(http.MethodPost)
+	router.HandleFunc("/service-accounts/{id}/credentials/{credentialId}/revoke", h.RevokeServiceAccountCredential).Methods(http.MethodPost)
+
+	// Campanhas de recertificação de acessos: decisões dos revisores, escalamento, revogação no prazo e atestação
+	router.HandleFunc("/recertification/campaigns", h.ListRecertificationCampaigns).Methods(http.MethodGet)
+	router.HandleFunc("/recertification/campaigns", h.CreateRecertificationCampaign).Methods(http.MethodPost)
+	router.HandleFunc("/recertification/campaigns/{id}", h.GetRecertificationCampaign).Methods(http.MethodGet)
+	router.HandleFunc("/recertification/campaigns/{id}/cancel", h.CancelRecertificationCampaign).Methods(http.MethodPost)
+	router.HandleFunc("/recertification/campaigns/{id}/items", h.ListRecertificationItems).Methods(http.MethodGet)
+	router.HandleFunc("/recertification/campaigns/{id}/attestation", h.GetRecertificationAttestation).Methods(http.MethodGet)
+	router.HandleFunc("/recertification/reviews", h.ListRecertificationReviews).Methods(http.MethodGet)
+	router.HandleFunc("/recertification/items/{id}/decision", h.DecideRecertificationItem).Methods(http.MethodPost)
+
+	// Atributos personalizados dos usuários: esquemas tipados por tenant, contexto ABAC e claims dos tokens
+	router.HandleFunc("/user-attribute-schemas", h.ListUserAttributeSchemas).Methods(http.MethodGet)
+	router.HandleFunc("/user-attribute-schemas", h.CreateUserAttributeSchema).Methods(http.MethodPost)
+	router.HandleFunc("/user-attribute-schemas/{id}", h.GetUserAttributeSchema).Methods(http.MethodGet)
+	router.HandleFunc("/user-attribute-schemas/{id}", h.UpdateUserAttributeSchema).Methods(http.MethodPut)
+	router.HandleFunc("/user-attribute-schemas/{id}", h.DeleteUserAttributeSchema).Methods(http.MethodDelete)
+	router.HandleFunc("/users/{userId}/attributes", h.GetUserAttributes).Methods(http.MethodGet)
+	router.HandleFunc("/users/{userId}/attributes", h.SetUserAttributes).Methods(http.MethodPut)
+	router.HandleFunc("/users/{userId}/claims", h.GetUserClaims).Methods(http.MethodGet)
+
+	// Notificações de logins suspeitos: canais e modelos por tenant e resposta "não fui eu" pelo link enviado
+	router.HandleFunc("/login-notifications/settings", h.GetLoginNotificationSettings).Methods(http.MethodGet)
+	router.HandleFunc("/login-notifications/settings", h.UpdateLoginNotificationSettings).Methods(http.MethodPut)
+	router.HandleFunc("/login-notifications/templates", h.ListLoginNotificationTemplates).Methods(http.MethodGet)
+	router.HandleFunc("/login-notifications/templates", h.SaveLoginNotificationTemplate).Methods(http.MethodPut)
+	router.HandleFunc("/login-notifications/templates/{id}", h.DeleteLoginNotificationTemplate).Methods(http.MethodDelete)
+	router.HandleFunc("/login-notifications/respond", h.RespondLoginNotification).Methods(http.MethodPost)
+	router.HandleFunc("/users/{userId}/login-notifications", h.ListUserLoginNotifications).Methods(http.MethodGet)
+
+	// Limites de permissões: conjunto máximo de permissões das funções e dos administradores delegados
+	router.HandleFunc("/permission-boundaries", h.ListPermissionBoundaries).Methods(http.MethodGet)
+	router.HandleFunc("/permission-boundaries", h.CreatePermissionBoundary).Methods(http.MethodPost)
+	router.HandleFunc("/permission-boundaries/{id}", h.GetPermissionBoundary).Methods(http.MethodGet)
+	router.HandleFunc("/permission-boundaries/{id}", h.UpdatePermissionBoundary).Methods(http.MethodPut)
+	router.HandleFunc("/permission-boundaries/{id}", h.DeletePermissionBoundary).Methods(http.MethodDelete)
+
+	// Operações em massa sobre usuários: desativação e atribuição de funções com resultado por usuário e retoma
+	router.HandleFunc("/bulk-user-jobs", h.SubmitBulkUserJob).Methods(http.MethodPost)
+	router.HandleFunc("/bulk-user-jobs", h.ListBulkUserJobs).Methods(http.MethodGet)
+	router.HandleFunc("/bulk-user-jobs/{id}", h.GetBulkUserJob).Methods(http.MethodGet)
+	router.HandleFunc("/bulk-user-jobs/{id}/items", h.ListBulkUserJobItems).Methods(http.MethodGet)
+	router.HandleFunc("/bulk-user-jobs/{id}/resume", h.ResumeBulkUserJob).Methods(http.MethodPost)
+
+	// Identidade visual e modelos de email do tenant, com versões, pré-visualização e publicação
+	router.HandleFunc("/branding", h.GetTenantBranding).Methods(http.MethodGet)
+	router.HandleFunc("/branding", h.UpdateTenantBranding).Methods(http.MethodPut)
+	router.HandleFunc("/email-templates/variables", h.ListEmailTemplateVariables).Methods(http.MethodGet)
+	router.HandleFunc("/email-templates/preview", h.PreviewEmail).Methods(http.MethodPost)
+	router.HandleFunc("/email-templates", h.ListEmailTemplates).Methods(http.MethodGet)
+	router.HandleFunc("/email-templates", h.CreateEmailTemplate).Methods(http.MethodPost)
+	router.HandleFunc("/email-templates/{id}", h.GetEmailTemplate).Methods(http.MethodGet)
+	router.HandleFunc("/email-templates/{id}/publish", h.PublishEmailTemplate).Methods(http.MethodPost)
+	router.HandleFunc("/email-templates/{id}/archive", h.ArchiveEmailTemplate).Methods(http.MethodPost)
+
+	// Registo de dispositivos: dispositivos vistos nos logins de cada usuário e confiança que dispensa o MFA
+	router.HandleFunc("/device-policy", h.GetDevicePolicy).Methods(http.MethodGet)
+	router.HandleFunc("/device-policy", h.UpdateDevicePolicy).Methods(http.MethodPut)
+	router.HandleFunc("/users/{userId}/devices", h.ListUserDevices).Methods(http.MethodGet)
+	router.HandleFunc("/users/{userId}/devices/trust-check", h.CheckUserDeviceTrust).Methods(http.MethodPost)
+	router.HandleFunc("/users/{userId}/devices/{id}", h.RenameUserDevice).Methods(http.MethodPut)
+	router.HandleFunc("/users/{userId}/devices/{id}/trust", h.TrustUserDevice).Methods(http.MethodPost)
+	router.HandleFunc("/users/{userId}/devices/{id}/trust", h.UntrustUserDevice).Methods(http.MethodDelete)
+	router.HandleFunc("/users/{userId}/devices/{id}/revoke", h.RevokeUserDevice).Methods(http.MethodPost)
 }
 
 // Estruturas auxiliares para manipulação de requisições e respostas
 
 // errorResponse representa uma resposta de erro padronizada
+// O código é estável entre idiomas; a mensagem é traduzida conforme o Accept-Language
 type errorResponse struct {
-	Status  int    `json:"status"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Status  int       `json:"status"`
+	Code    i18n.Code `json:"code"`
+	Message string    `json:"message"`
+	Locale  string    `json:"locale"`
+	Details string    `json:"details,omitempty"`
+	TraceID string    `json:"traceId,omitempty"`
 }
 
 // paginationResponse representa informações de paginação na resposta
@@ -93,6 +343,12 @@ type response struct {
 	Pagination *paginationResponse  `json:"pagination,omitempty"`
 }
 
+// ErrorResponse e PaginationResponse expõem os envelopes da API ao gerador do contrato OpenAPI
+type (
+	ErrorResponse      = errorResponse
+	PaginationResponse = paginationResponse
+)
+
 // getPagination extrai e valida parâmetros de paginação da request
 func (h *RoleHandler) getPagination(r *http.Request) model.Pagination {
 	pageStr := r.URL.Query().Get("page")
@@ -122,6 +378,11 @@ func (h *RoleHandler) getPagination(r *http.Request) model.Pagination {
 // getTenantID obtém o ID do tenant da requisição
 // Em um sistema real, isto viria de um middleware de autenticação ou token JWT
 func (h *RoleHandler) getTenantID(r *http.Request) uuid.UUID {
+	// O tenant injetado pelo middleware de autenticação prevalece sobre os headers
+	if tenantID, ok := requestctx.TenantFromContext(r.Context()); ok {
+		return tenantID
+	}
+
 	// Implementação de exemplo - em ambiente real, isso viria de um token autenticado
 	tenantID := r.Header.Get("X-Tenant-ID")
 	if tenantID == "" {
@@ -141,6 +402,10 @@ func (h *RoleHandler) getTenantID(r *http.Request) uuid.UUID {
 // getUserID obtém o ID do usuário autenticado da requisição
 // Em um sistema real, isto viria de um middleware de autenticação ou token JWT
 func (h *RoleHandler) getUserID(r *http.Request) uuid.UUID {
+	if actor, ok := requestctx.ActorFromContext(r.Context()); ok {
+		return actor.UserID
+	}
+
 	// Implementação de exemplo - em ambiente real, isso viria de um token autenticado
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
@@ -168,13 +433,31 @@ func (h *RoleHandler) respondWithJSON(w http.ResponseWriter, status int, data in
 	}
 }
 
-// respondWithError envia uma resposta de erro em formato JSON
-func (h *RoleHandler) respondWithError(w http.ResponseWriter, status int, code, message string) {
-	h.respondWithJSON(w, status, errorResponse{
+// respondWithError envia uma resposta de erro em formato JSON com a mensagem no idioma da requisição
+// O erro de origem, quando informado, é devolvido em details sem tradução
+func (h *RoleHandler) respondWithError(w http.ResponseWriter, r *http.Request, status int, code i18n.Code, cause error) {
+	h.respondWithErrorMessage(w, r, status, code, i18n.MessageKey(code), cause)
+}
+
+// respondWithErrorMessage envia o código de erro com uma mensagem mais específica do que a mensagem padrão do código
+func (h *RoleHandler) respondWithErrorMessage(w http.ResponseWriter, r *http.Request, status int, code i18n.Code, message i18n.MessageKey, cause error) {
+	locale := i18n.FromRequest(r)
+
+	resp := errorResponse{
 		Status:  status,
 		Code:    code,
-		Message: message,
-	})
+		Message: i18n.Default.Text(locale, message),
+		Locale:  locale,
+	}
+	if cause != nil {
+		resp.Details = cause.Error()
+	}
+	if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+		resp.TraceID = spanContext.TraceID().String()
+	}
+
+	w.Header().Set("Content-Language", locale)
+	h.respondWithJSON(w, status, resp)
 }
 
 // parseExpirationTime analisa um parâmetro de data de expiração em formato string
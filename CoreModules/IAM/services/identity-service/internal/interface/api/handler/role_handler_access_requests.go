@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// Visões da listagem de pedidos de acesso
+const (
+	accessRequestViewMine    = "mine"
+	accessRequestViewPending = "pending"
+)
+
+// AccessRequestCreateRequest representa o pedido de uma função ou permissão pelo usuário autenticado
+type AccessRequestCreateRequest struct {
+	TargetType    string `json:"targetType"`
+	TargetID      string `json:"targetId"`
+	Justification string `json:"justification"`
+	ExpiresAt     string `json:"expiresAt,omitempty"`
+}
+
+// AccessRequestDecisionRequest representa a decisão de um aprovador
+// A validade só se aplica à aprovação e substitui a validade pedida
+type AccessRequestDecisionRequest struct {
+	Reason    string `json:"reason,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// AccessApproverRequest representa a atribuição de autoridade de aprovação a um usuário
+type AccessApproverRequest struct {
+	UserID string `json:"userId"`
+	Kind   string `json:"kind"`
+	RoleID string `json:"roleId,omitempty"`
+}
+
+// SetAccessRequestService configura o serviço de pedidos de acesso usado pelo handler
+func (h *RoleHandler) SetAccessRequestService(accessRequestService application.AccessRequestService) {
+	h.accessRequestService = accessRequestService
+}
+
+// SubmitAccessRequest regista um pedido de acesso do usuário autenticado
+func (h *RoleHandler) SubmitAccessRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.SubmitAccessRequest")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	var req AccessRequestCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	targetID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
+		return
+	}
+
+	expiresAt, err := h.parseExpirationTime(req.ExpiresAt)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExpiration, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	requesterID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("requester.id", requesterID.String()),
+		attribute.String("target.type", req.TargetType),
+		attribute.String("target.id", targetID.String()),
+	)
+
+	accessRequest, err := h.accessRequestService.Submit(ctx, &application.SubmitAccessRequest{
+		TenantID:      tenantID,
+		RequesterID:   requesterID,
+		TargetType:    model.AccessRequestTarget(req.TargetType),
+		TargetID:      targetID,
+		Justification: req.Justification,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, accessRequest)
+}
+
+// ListAccessRequests lista os pedidos do usuário autenticado ou, com view=pending,
+// os pedidos pendentes que ele pode decidir
+func (h *RoleHandler) ListAccessRequests(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListAccessRequests")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	view := r.URL.Query().Get("view")
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("view", view),
+	)
+
+	var (
+		requests []*model.AccessRequest
+		err      error
+	)
+	switch view {
+	case "", accessRequestViewMine:
+		requests, err = h.accessRequestService.ListByRequester(ctx, tenantID, userID)
+	case accessRequestViewPending:
+		requests, err = h.accessRequestService.ListPendingForApprover(ctx, tenantID, userID)
+	default:
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, requests)
+}
+
+// GetAccessRequest obtém um pedido de acesso
+func (h *RoleHandler) GetAccessRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetAccessRequest")
+	defer span.End()
+
+	tenantID, requestID, ok := h.accessRequestRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	accessRequest, err := h.accessRequestService.Get(ctx, tenantID, requestID)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accessRequest)
+}
+
+// ApproveAccessRequest aprova um pedido de acesso e concede o acesso ao requerente
+func (h *RoleHandler) ApproveAccessRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ApproveAccessRequest")
+	defer span.End()
+
+	decision, ok := h.accessRequestDecision(w, r, span)
+	if !ok {
+		return
+	}
+
+	accessRequest, err := h.accessRequestService.Approve(ctx, decision)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, decision.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accessRequest)
+}
+
+// RejectAccessRequest rejeita um pedido de acesso
+func (h *RoleHandler) RejectAccessRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RejectAccessRequest")
+	defer span.End()
+
+	decision, ok := h.accessRequestDecision(w, r, span)
+	if !ok {
+		return
+	}
+
+	accessRequest, err := h.accessRequestService.Reject(ctx, decision)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, decision.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accessRequest)
+}
+
+// CancelAccessRequest retira um pedido pendente do usuário autenticado
+func (h *RoleHandler) CancelAccessRequest(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CancelAccessRequest")
+	defer span.End()
+
+	tenantID, requestID, ok := h.accessRequestRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	accessRequest, err := h.accessRequestService.Cancel(ctx, tenantID, requestID, h.getUserID(r))
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accessRequest)
+}
+
+// GetAccessRequestSLA obtém as métricas de prazo de decisão dos pedidos do tenant
+func (h *RoleHandler) GetAccessRequestSLA(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetAccessRequestSLA")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	metrics, err := h.accessRequestService.GetSLAMetrics(ctx, tenantID)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, metrics)
+}
+
+// ListAccessApprovers lista os aprovadores de pedidos de acesso do tenant
+func (h *RoleHandler) ListAccessApprovers(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListAccessApprovers")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	approvers, err := h.accessRequestService.ListApprovers(ctx, tenantID)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, approvers)
+}
+
+// AddAccessApprover atribui a um usuário a autoridade para decidir pedidos de acesso
+func (h *RoleHandler) AddAccessApprover(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.AddAccessApprover")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	var req AccessApproverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+
+	approver := &model.AccessRequestApprover{
+		TenantID:  h.getTenantID(r),
+		UserID:    userID,
+		Kind:      model.AccessApproverKind(req.Kind),
+		CreatedBy: h.getUserID(r),
+	}
+	if req.RoleID != "" {
+		roleID, err := uuid.Parse(req.RoleID)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
+			return
+		}
+		approver.RoleID = &roleID
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", approver.TenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("approver.kind", req.Kind),
+	)
+
+	approver, err = h.accessRequestService.AddApprover(ctx, approver)
+	if err != nil {
+		h.respondWithAccessRequestError(w, r, span, h.getTenantID(r), err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, approver)
+}
+
+// RemoveAccessApprover retira a autoridade de um aprovador
+func (h *RoleHandler) RemoveAccessApprover(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RemoveAccessApprover")
+	defer span.End()
+
+	if !h.accessRequestsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	approverID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidApproverID, nil)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("approver.id", approverID.String()),
+	)
+
+	if err := h.accessRequestService.RemoveApprover(ctx, tenantID, approverID); err != nil {
+		h.respondWithAccessRequestError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// accessRequestsEnabled responde 501 quando o serviço de pedidos de acesso não está configurado
+func (h *RoleHandler) accessRequestsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.accessRequestService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// accessRequestRequest valida a disponibilidade do serviço e extrai o tenant e o pedido
+func (h *RoleHandler) accessRequestRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.accessRequestsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	requestID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidAccessRequestID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("access_request.id", requestID.String()),
+	)
+	return tenantID, requestID, true
+}
+
+// accessRequestDecision extrai a decisão do aprovador autenticado sobre o pedido
+func (h *RoleHandler) accessRequestDecision(w http.ResponseWriter, r *http.Request, span trace.Span) (*application.AccessRequestDecision, bool) {
+	tenantID, requestID, ok := h.accessRequestRequest(w, r, span)
+	if !ok {
+		return nil, false
+	}
+
+	var req AccessRequestDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return nil, false
+	}
+
+	expiresAt, err := h.parseExpirationTime(req.ExpiresAt)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExpiration, nil)
+		return nil, false
+	}
+
+	approverID := h.getUserID(r)
+	span.SetAttributes(attribute.String("approver.id", approverID.String()))
+
+	return &application.AccessRequestDecision{
+		TenantID:   tenantID,
+		RequestID:  requestID,
+		ApproverID: approverID,
+		Reason:     req.Reason,
+		ExpiresAt:  expiresAt,
+	}, true
+}
+
+// respondWithAccessRequestError mapeia os erros dos pedidos de acesso para códigos HTTP apropriados
+func (h *RoleHandler) respondWithAccessRequestError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar pedido de acesso")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrAccessRequestNotFound),
+		errors.Is(err, application.ErrAccessApproverNotFound),
+		errors.Is(err, application.ErrRoleNotFound),
+		errors.Is(err, application.ErrPermissionNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidAccessRequest),
+		errors.Is(err, application.ErrAccessRequestJustificationRequired),
+		errors.Is(err, application.ErrInvalidAccessApprover):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrAccessRequestSelfApproval),
+		errors.Is(err, application.ErrAccessRequestApproverNotAuthorized),
+		errors.Is(err, application.ErrAccessRequestNotRequester):
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+	case errors.Is(err, application.ErrDuplicateAccessRequest),
+		errors.Is(err, application.ErrAccessAlreadyGranted),
+		errors.Is(err, application.ErrAccessRequestNotPending):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrAccessRequestConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConcurrentModification, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar pedido de acesso")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// Cabeçalho com o instante (RFC 3339) da última autenticação do usuário, preenchido pelo gateway
+// a partir da claim auth_time do token; a ligação e a remoção de identidades exigem que seja recente
+const accountLinkAuthTimeHeader = "X-Auth-Time"
+
+// SetAccountLinkService configura o serviço de ligação de contas usado pelo handler
+func (h *RoleHandler) SetAccountLinkService(accountLinkService application.AccountLinkService) {
+	h.accountLinkService = accountLinkService
+}
+
+// ListLinkedIdentities lista os métodos de login do usuário autenticado
+func (h *RoleHandler) ListLinkedIdentities(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListLinkedIdentities")
+	defer span.End()
+
+	if !h.accountLinkEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	identities, err := h.accountLinkService.ListLinkedIdentities(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, identities)
+}
+
+// UnlinkIdentity remove uma identidade federada da conta do usuário autenticado
+func (h *RoleHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UnlinkIdentity")
+	defer span.End()
+
+	if !h.accountLinkEnabled(w, r) {
+		return
+	}
+
+	identityID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidLinkedIdentityID, nil)
+		return
+	}
+	authenticatedAt, ok := h.accountLinkAuthTime(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("federated_identity.id", identityID.String()),
+	)
+
+	err = h.accountLinkService.Unlink(ctx, &application.UnlinkAccountRequest{
+		TenantID:        tenantID,
+		UserID:          userID,
+		IdentityID:      identityID,
+		AuthenticatedAt: authenticatedAt,
+		IPAddress:       h.clientIP(r),
+	})
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAccountLinkCandidates lista os candidatos à ligação pendentes do usuário autenticado
+func (h *RoleHandler) ListAccountLinkCandidates(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListAccountLinkCandidates")
+	defer span.End()
+
+	if !h.accountLinkEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	candidates, err := h.accountLinkService.ListCandidates(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, candidates)
+}
+
+// LinkAccountCandidate liga um candidato à conta do usuário autenticado
+func (h *RoleHandler) LinkAccountCandidate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.LinkAccountCandidate")
+	defer span.End()
+
+	tenantID, userID, candidateID, ok := h.accountLinkCandidateRequest(w, r, span)
+	if !ok {
+		return
+	}
+	authenticatedAt, ok := h.accountLinkAuthTime(w, r)
+	if !ok {
+		return
+	}
+
+	identity, err := h.accountLinkService.Link(ctx, &application.LinkAccountRequest{
+		TenantID:        tenantID,
+		UserID:          userID,
+		CandidateID:     candidateID,
+		AuthenticatedAt: authenticatedAt,
+		IPAddress:       h.clientIP(r),
+	})
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, identity)
+}
+
+// DismissAccountLinkCandidate recusa um candidato à ligação do usuário autenticado
+func (h *RoleHandler) DismissAccountLinkCandidate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DismissAccountLinkCandidate")
+	defer span.End()
+
+	tenantID, userID, candidateID, ok := h.accountLinkCandidateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	candidate, err := h.accountLinkService.DismissCandidate(ctx, tenantID, userID, candidateID, h.clientIP(r))
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, candidate)
+}
+
+// ListAccountLinkEvents lista o registo de auditoria da ligação de contas do tenant
+// Filtros opcionais: user_id, action, since (RFC 3339) e limit
+func (h *RoleHandler) ListAccountLinkEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListAccountLinkEvents")
+	defer span.End()
+
+	if !h.accountLinkEnabled(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.AccountLinkEventFilter{Action: model.AccountLinkAction(query.Get("action"))}
+	if value := query.Get("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+			return
+		}
+		filter.UserID = &userID
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.action", string(filter.Action)),
+	)
+
+	events, err := h.accountLinkService.ListEvents(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithAccountLinkError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, events)
+}
+
+// accountLinkEnabled responde 501 quando a ligação de contas não está configurada
+func (h *RoleHandler) accountLinkEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.accountLinkService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// accountLinkCandidateRequest valida a disponibilidade do serviço e extrai o tenant, o usuário e o candidato
+func (h *RoleHandler) accountLinkCandidateRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	if !h.accountLinkEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	candidateID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidAccountLinkCandidateID, nil)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+		attribute.String("account_link_candidate.id", candidateID.String()),
+	)
+	return tenantID, userID, candidateID, true
+}
+
+// accountLinkAuthTime lê o instante da última autenticação do usuário
+// Sem cabeçalho, o instante fica vazio e o serviço exige nova autenticação
+func (h *RoleHandler) accountLinkAuthTime(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	value := r.Header.Get(accountLinkAuthTimeHeader)
+	if value == "" {
+		return time.Time{}, true
+	}
+	authenticatedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+		return time.Time{}, false
+	}
+	return authenticatedAt, true
+}
+
+// respondWithAccountLinkError mapeia os erros da ligação de contas para códigos HTTP apropriados
+func (h *RoleHandler) respondWithAccountLinkError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar ligação de contas")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrAccountLinkCandidateNotFound), errors.Is(err, application.ErrLinkedIdentityNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidAccountLinkFilter), errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrReauthenticationRequired):
+		h.respondWithError(w, r, http.StatusUnauthorized, i18n.CodeReauthenticationRequired, err)
+	case errors.Is(err, application.ErrIdentityAlreadyLinked):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrAccountLinkCandidateClosed):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeOperationNotAllowed, err)
+	case errors.Is(err, application.ErrLastLinkedIdentity):
+		h.respondWithError(w, r, http.StatusUnprocessableEntity, i18n.CodeOperationNotAllowed, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar ligação de contas")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// AuditLogNextCursorTrailer é o trailer da exportação CSV com o cursor para continuar
+// uma exportação que atingiu o limite de linhas
+const AuditLogNextCursorTrailer = "X-Audit-Log-Next-Cursor"
+
+// SetAuditLogService configura o serviço de consulta dos registos de auditoria usado pelo handler
+func (h *RoleHandler) SetAuditLogService(auditLogService application.AuditLogService) {
+	h.auditLogService = auditLogService
+}
+
+// SearchAuditLogs consulta os registos de auditoria do tenant, do mais recente para o mais antigo,
+// com pesquisa em texto livre nos detalhes e filtros por ator, alvo, evento, severidade, mercado e período
+func (h *RoleHandler) SearchAuditLogs(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.SearchAuditLogs")
+	defer span.End()
+
+	if !h.auditLogsEnabled(w, r) {
+		return
+	}
+
+	filter, ok := h.auditLogFilter(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	page, err := h.auditLogService.Search(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithAuditLogError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, page)
+}
+
+// ExportAuditLogs descarrega em CSV os registos de auditoria do tenant que satisfazem os filtros
+// Quando a exportação atinge o limite de linhas, o trailer X-Audit-Log-Next-Cursor indica o cursor para continuar
+func (h *RoleHandler) ExportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ExportAuditLogs")
+	defer span.End()
+
+	if !h.auditLogsEnabled(w, r) {
+		return
+	}
+
+	filter, ok := h.auditLogFilter(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	// Os cabeçalhos só são enviados com a primeira linha, para que um erro inicial ainda tenha resposta própria
+	filename := "audit-logs-" + time.Now().UTC().Format("20060102T150405Z") + ".csv"
+	out := &auditLogExportWriter{w: w, filename: filename}
+	result, err := h.auditLogService.Export(ctx, tenantID, filter, out)
+	if err != nil {
+		if !out.started {
+			h.respondWithAuditLogError(w, r, span, tenantID, err)
+			return
+		}
+		span.SetStatus(codes.Error, "Falha ao exportar registos de auditoria")
+		span.RecordError(err)
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao enviar exportação dos registos de auditoria")
+		return
+	}
+
+	span.SetAttributes(attribute.Int("audit_log.rows", result.Rows))
+	if result.NextCursor != "" {
+		w.Header().Set(AuditLogNextCursorTrailer, result.NextCursor)
+	}
+}
+
+// auditLogExportWriter envia os cabeçalhos da exportação CSV antes dos primeiros dados
+type auditLogExportWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (e *auditLogExportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		e.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": e.filename}))
+		e.w.Header().Set("Trailer", AuditLogNextCursorTrailer)
+		e.w.WriteHeader(http.StatusOK)
+	}
+	n, err := e.w.Write(p)
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// auditLogFilter interpreta os parâmetros da consulta dos registos de auditoria
+// Os tipos de evento e as severidades aceitam listas separadas por vírgulas
+func (h *RoleHandler) auditLogFilter(w http.ResponseWriter, r *http.Request) (model.AuditLogFilter, bool) {
+	query := r.URL.Query()
+	filter := model.AuditLogFilter{
+		Query:      strings.TrimSpace(query.Get("q")),
+		TargetType: query.Get("target_type"),
+		EventTypes: splitAuditLogList(query.Get("event_type")),
+		Market:     query.Get("market"),
+	}
+	for _, severity := range splitAuditLogList(query.Get("severity")) {
+		filter.Severities = append(filter.Severities, model.AuditSeverity(strings.ToLower(severity)))
+	}
+	for name, target := range map[string]**uuid.UUID{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if value := query.Get(name); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+				return filter, false
+			}
+			*target = &parsed
+		}
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+				return filter, false
+			}
+			*target = &parsed
+		}
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := model.ParseAuditLogCursor(value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+			return filter, false
+		}
+		filter.Cursor = cursor
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return filter, false
+		}
+		filter.Limit = limit
+	}
+	return filter, true
+}
+
+// splitAuditLogList separa uma lista de valores separados por vírgulas, ignorando os vazios
+func splitAuditLogList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// auditLogsEnabled responde 501 quando a consulta dos registos de auditoria não está configurada
+func (h *RoleHandler) auditLogsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.auditLogService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// respondWithAuditLogError mapeia os erros da consulta dos registos de auditoria para códigos HTTP apropriados
+func (h *RoleHandler) respondWithAuditLogError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao consultar registos de auditoria")
+	span.RecordError(err)
+
+	if errors.Is(err, application.ErrInvalidAuditLogFilter) {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+		return
+	}
+	h.logger.Error().Err(err).
+		Str("tenant_id", tenantID.String()).
+		Msg("Erro ao consultar registos de auditoria")
+	h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// Tamanho máximo do conteúdo de uma tarefa de operação em massa
+const maxBulkUserPayloadSize = 32 << 20
+
+// bulkUserPayloadFormats associa os tipos de conteúdo aceites aos formatos do conteúdo
+var bulkUserPayloadFormats = map[string]application.BulkUserPayloadFormat{
+	"application/x-ndjson": application.BulkUserPayloadNDJSON,
+	"application/jsonl":    application.BulkUserPayloadNDJSON,
+	"text/csv":             application.BulkUserPayloadCSV,
+}
+
+// SetBulkUserJobService configura o serviço das operações em massa sobre usuários usado pelo handler
+func (h *RoleHandler) SetBulkUserJobService(bulkUserJobService application.BulkUserJobService) {
+	h.bulkUserJobService = bulkUserJobService
+}
+
+// SubmitBulkUserJob regista uma operação em massa sobre os usuários enviados em NDJSON ou CSV
+// A operação e os valores por omissão dos itens são indicados na query; a execução é assíncrona
+func (h *RoleHandler) SubmitBulkUserJob(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.SubmitBulkUserJob")
+	defer span.End()
+
+	if !h.bulkUserJobsEnabled(w, r) {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := bulkUserPayloadFormats[mediaType]
+	if !ok {
+		h.respondWithError(w, r, http.StatusUnsupportedMediaType, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	query := r.URL.Query()
+	req := &application.SubmitBulkUserJobRequest{
+		TenantID:    h.getTenantID(r),
+		RequestedBy: h.getUserID(r),
+		Operation:   model.BulkUserOperation(query.Get("operation")),
+		Reason:      query.Get("reason"),
+		Format:      format,
+		Payload:     http.MaxBytesReader(w, r.Body, maxBulkUserPayloadSize),
+	}
+	if roleIDStr := query.Get("role_id"); roleIDStr != "" {
+		roleID, err := uuid.Parse(roleIDStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
+			return
+		}
+		req.RoleID = &roleID
+	}
+	if expiresAtStr := query.Get("expires_at"); expiresAtStr != "" {
+		expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExpiration, nil)
+			return
+		}
+		req.ExpiresAt = &expiresAt
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", req.TenantID.String()),
+		attribute.String("actor.id", req.RequestedBy.String()),
+		attribute.String("bulk_user_job.operation", string(req.Operation)),
+		attribute.String("bulk_user_job.format", string(format)),
+	)
+
+	job, err := h.bulkUserJobService.Submit(ctx, req)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondWithError(w, r, http.StatusRequestEntityTooLarge, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		h.respondWithBulkUserJobError(w, r, span, req.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// ListBulkUserJobs lista as tarefas de operação em massa do tenant, da mais recente para a mais antiga
+func (h *RoleHandler) ListBulkUserJobs(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListBulkUserJobs")
+	defer span.End()
+
+	if !h.bulkUserJobsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	jobs, err := h.bulkUserJobService.List(ctx, tenantID)
+	if err != nil {
+		h.respondWithBulkUserJobError(w, r, span, tenantID, err)
+		return
+	}
+	if jobs == nil {
+		jobs = []*model.BulkUserJob{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, jobs)
+}
+
+// GetBulkUserJob obtém o estado e os contadores de uma tarefa de operação em massa
+func (h *RoleHandler) GetBulkUserJob(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetBulkUserJob")
+	defer span.End()
+
+	tenantID, jobID, ok := h.bulkUserJobRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkUserJobService.Get(ctx, tenantID, jobID)
+	if err != nil {
+		h.respondWithBulkUserJobError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, job)
+}
+
+// ListBulkUserJobItems lista o resultado de cada usuário de uma tarefa, pela linha do conteúdo enviado
+func (h *RoleHandler) ListBulkUserJobItems(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListBulkUserJobItems")
+	defer span.End()
+
+	tenantID, jobID, ok := h.bulkUserJobRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	after := 0
+	if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+		if parsed, err := strconv.Atoi(afterStr); err == nil && parsed > 0 {
+			after = parsed
+		}
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	status := model.BulkUserItemStatus(r.URL.Query().Get("status"))
+
+	page, err := h.bulkUserJobService.ListItems(ctx, tenantID, jobID, status, after, limit)
+	if err != nil {
+		h.respondWithBulkUserJobError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, page)
+}
+
+// ResumeBulkUserJob retoma uma tarefa falhada ou concluída com erros, voltando a processar os itens falhados
+func (h *RoleHandler) ResumeBulkUserJob(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ResumeBulkUserJob")
+	defer span.End()
+
+	tenantID, jobID, ok := h.bulkUserJobRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkUserJobService.Resume(ctx, tenantID, jobID)
+	if err != nil {
+		h.respondWithBulkUserJobError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// bulkUserJobsEnabled responde 501 quando as operações em massa não estão configuradas
+func (h *RoleHandler) bulkUserJobsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.bulkUserJobService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// bulkUserJobRequest valida a disponibilidade do serviço e extrai o tenant e a tarefa
+func (h *RoleHandler) bulkUserJobRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.bulkUserJobsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidBulkUserJobID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("bulk_user_job.id", jobID.String()),
+	)
+	return tenantID, jobID, true
+}
+
+// respondWithBulkUserJobError mapeia os erros das operações em massa para códigos HTTP apropriados
+func (h *RoleHandler) respondWithBulkUserJobError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar operação em massa sobre usuários")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrBulkUserJobNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidBulkUserJob):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrBulkUserJobConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConcurrentModification, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar operação em massa sobre usuários")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
 	"innovabiz/iam/identity-service/internal/domain/model"
 )
 
@@ -42,8 +43,17 @@ type RoleResponse struct {
 	Version     int                    `json:"version"`
 }
 
+// CloneRoleRequest representa o modelo de dados para clonagem de uma função
+type CloneRoleRequest struct {
+	NewCode        string `json:"newCode"`
+	NewName        string `json:"newName"`
+	CloneHierarchy bool   `json:"cloneHierarchy"`
+	CloneUsers     bool   `json:"cloneUsers"`
+}
+
 // toRoleResponse converte um modelo de domínio Role para RoleResponse
-func toRoleResponse(role *model.Role) RoleResponse {
+// Os metadados omitem as chaves que o usuário não pode ler segundo as políticas dos metadados
+func toRoleResponse(role *model.Role, access *model.RoleMetadataAccess) RoleResponse {
 	response := RoleResponse{
 		ID:          role.ID,
 		TenantID:    role.TenantID,
@@ -53,7 +63,7 @@ func toRoleResponse(role *model.Role) RoleResponse {
 		Type:        role.Type,
 		IsSystem:    role.IsSystem,
 		IsActive:    role.IsActive,
-		Metadata:    role.Metadata,
+		Metadata:    access.FilterReadable(role.Metadata),
 		CreatedAt:   role.CreatedAt,
 		CreatedBy:   role.CreatedBy,
 		Version:     role.Version,
@@ -68,10 +78,10 @@ func toRoleResponse(role *model.Role) RoleResponse {
 }
 
 // toRoleResponseList converte uma lista de modelos de domínio Role para []RoleResponse
-func toRoleResponseList(roles []*model.Role) []RoleResponse {
+func toRoleResponseList(roles []*model.Role, access *model.RoleMetadataAccess) []RoleResponse {
 	responseList := make([]RoleResponse, len(roles))
 	for i, role := range roles {
-		responseList[i] = toRoleResponse(role)
+		responseList[i] = toRoleResponse(role, access)
 	}
 	return responseList
 }
@@ -93,21 +103,31 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&roleRequest); err != nil {
 		span.SetStatus(codes.Error, "Falha ao decodificar requisição")
 		span.RecordError(err)
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
 		return
 	}
 
 	// Validações básicas
 	if roleRequest.Code == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Código da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleCodeRequired, nil)
 		return
 	}
 	if roleRequest.Name == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Nome da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleNameRequired, nil)
 		return
 	}
 	if roleRequest.Type == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Tipo da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleTypeRequired, nil)
+		return
+	}
+
+	// Recusar chaves protegidas dos metadados que o usuário não pode alterar
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+	metadata, ok := h.applyRoleMetadataWrite(w, r, span, access, nil, roleRequest.Metadata)
+	if !ok {
 		return
 	}
 
@@ -119,7 +139,7 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 		Description: roleRequest.Description,
 		Type:        roleRequest.Type,
 		IsActive:    roleRequest.IsActive,
-		Metadata:    roleRequest.Metadata,
+		Metadata:    metadata,
 	}
 
 	// Criar função no serviço
@@ -131,21 +151,21 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("code", roleRequest.Code).
 				Msg("Erro ao criar função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
 	// Responder com a função criada
-	h.respondWithJSON(w, http.StatusCreated, toRoleResponse(createdRole))
+	h.respondWithJSON(w, http.StatusCreated, toRoleResponse(createdRole, access))
 }
 
 // GetRole obtém uma função por ID
@@ -157,7 +177,7 @@ func (h *RoleHandler) GetRole(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -174,19 +194,24 @@ func (h *RoleHandler) GetRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Responder com a função
-	h.respondWithJSON(w, http.StatusOK, toRoleResponse(role))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponse(role, access))
 }
 
 // ListRoles lista funções com filtros e paginação
@@ -232,7 +257,12 @@ func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error().Err(err).
 			Str("tenant_id", tenantID.String()).
 			Msg("Erro ao listar funções")
-		h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+		return
+	}
+
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
 		return
 	}
 
@@ -244,7 +274,7 @@ func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
 
 	// Responder com a lista de funções e informações de paginação
 	h.respondWithJSON(w, http.StatusOK, response{
-		Data: toRoleResponseList(roles),
+		Data: toRoleResponseList(roles, access),
 		Pagination: &paginationResponse{
 			Page:       pagination.Page,
 			PageSize:   pagination.PageSize,
@@ -264,7 +294,7 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -278,21 +308,52 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&roleRequest); err != nil {
 		span.SetStatus(codes.Error, "Falha ao decodificar requisição")
 		span.RecordError(err)
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
 		return
 	}
 
 	// Validações básicas
 	if roleRequest.Code == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Código da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleCodeRequired, nil)
 		return
 	}
 	if roleRequest.Name == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Nome da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleNameRequired, nil)
 		return
 	}
 	if roleRequest.Type == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Tipo da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleTypeRequired, nil)
+		return
+	}
+
+	// Manter as chaves protegidas dos metadados que o usuário não pode alterar
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+	var currentMetadata map[string]interface{}
+	if access != nil {
+		currentRole, err := h.roleService.GetRoleByID(ctx, tenantID, roleID)
+		if err != nil {
+			span.SetStatus(codes.Error, "Falha ao obter função")
+			span.RecordError(err)
+
+			switch err.(type) {
+			case *application.ResourceNotFoundError:
+				h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+			default:
+				h.logger.Error().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("role_id", roleID.String()).
+					Msg("Erro ao obter função")
+				h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+			}
+			return
+		}
+		currentMetadata = currentRole.Metadata
+	}
+	metadata, ok := h.applyRoleMetadataWrite(w, r, span, access, currentMetadata, roleRequest.Metadata)
+	if !ok {
 		return
 	}
 
@@ -305,7 +366,7 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		Description: roleRequest.Description,
 		Type:        roleRequest.Type,
 		IsActive:    roleRequest.IsActive,
-		Metadata:    roleRequest.Metadata,
+		Metadata:    metadata,
 	}
 
 	// Atualizar função no serviço
@@ -317,25 +378,25 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		case *application.ConcurrentModificationError:
-			h.respondWithError(w, http.StatusPreconditionFailed, "concurrent_modification", err.Error())
+			h.respondWithError(w, r, http.StatusPreconditionFailed, i18n.CodeConcurrentModification, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao atualizar função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
 	// Responder com a função atualizada
-	h.respondWithJSON(w, http.StatusOK, toRoleResponse(updatedRole))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponse(updatedRole, access))
 }
 
 // DeleteRole exclui uma função
@@ -348,7 +409,7 @@ func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -375,20 +436,20 @@ func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.ResourceInUseError:
-			h.respondWithError(w, http.StatusConflict, "resource_in_use", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeResourceInUse, err)
 		case *application.OperationNotAllowedError:
-			h.respondWithError(w, http.StatusForbidden, "operation_not_allowed", err.Error())
+			h.respondWithError(w, r, http.StatusForbidden, i18n.CodeOperationNotAllowed, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Bool("permanent", permanent).
 				Msg("Erro ao excluir função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -407,7 +468,7 @@ func (h *RoleHandler) CloneRole(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sourceRoleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função fonte inválido")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidID, i18n.MessageInvalidSourceRoleID, nil)
 		return
 	}
 
@@ -417,28 +478,21 @@ func (h *RoleHandler) CloneRole(w http.ResponseWriter, r *http.Request) {
 		attribute.String("user.id", userID.String()),
 	)
 
-	type cloneRequest struct {
-		NewCode        string `json:"newCode"`
-		NewName        string `json:"newName"`
-		CloneHierarchy bool   `json:"cloneHierarchy"`
-		CloneUsers     bool   `json:"cloneUsers"`
-	}
-
-	var req cloneRequest
+	var req CloneRoleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		span.SetStatus(codes.Error, "Falha ao decodificar requisição")
 		span.RecordError(err)
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
 		return
 	}
 
 	// Validações básicas
 	if req.NewCode == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Novo código da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageNewRoleCodeRequired, nil)
 		return
 	}
 	if req.NewName == "" {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Novo nome da função é obrigatório")
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageNewRoleNameRequired, nil)
 		return
 	}
 
@@ -455,24 +509,29 @@ func (h *RoleHandler) CloneRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("source_role_id", sourceRoleID.String()).
 				Str("new_code", req.NewCode).
 				Msg("Erro ao clonar função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Responder com a função clonada
-	h.respondWithJSON(w, http.StatusCreated, toRoleResponse(clonedRole))
+	h.respondWithJSON(w, http.StatusCreated, toRoleResponse(clonedRole, access))
 }
 
 // SyncSystemRoles sincroniza as funções de sistema
@@ -497,10 +556,15 @@ func (h *RoleHandler) SyncSystemRoles(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error().Err(err).
 			Str("tenant_id", tenantID.String()).
 			Msg("Erro ao sincronizar funções de sistema")
-		h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+		return
+	}
+
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
 		return
 	}
 
 	// Responder com as funções sincronizadas
-	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(syncedRoles))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(syncedRoles, access))
 }
\ No newline at end of file
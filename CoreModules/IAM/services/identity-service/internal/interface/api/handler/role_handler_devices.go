@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// DevicePolicyRequest representa a política de dispositivos do tenant autenticado
+type DevicePolicyRequest struct {
+	TrustEnabled      bool `json:"trust_enabled"`
+	TrustDays         int  `json:"trust_days"`
+	MaxTrustedDevices int  `json:"max_trusted_devices"`
+	ReuseThreshold    int  `json:"reuse_threshold"`
+}
+
+// DeviceRenameRequest representa o novo nome de um dispositivo
+type DeviceRenameRequest struct {
+	Name string `json:"name"`
+}
+
+// DeviceTrustCheckRequest identifica o dispositivo de um login a avaliar pelos fluxos de MFA
+// Sem agente no corpo, é usado o cabeçalho User-Agent do pedido
+type DeviceTrustCheckRequest struct {
+	DeviceID  string `json:"device_id"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// SetDeviceService configura o serviço do registo de dispositivos usado pelo handler
+func (h *RoleHandler) SetDeviceService(deviceService application.DeviceService) {
+	h.deviceService = deviceService
+}
+
+// GetDevicePolicy obtém a política de dispositivos do tenant
+func (h *RoleHandler) GetDevicePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetDevicePolicy")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	policy, err := h.deviceService.GetPolicy(ctx, tenantID)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// UpdateDevicePolicy altera a política de dispositivos do tenant
+func (h *RoleHandler) UpdateDevicePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateDevicePolicy")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	var req DevicePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.Bool("device.trust_enabled", req.TrustEnabled),
+	)
+
+	policy, err := h.deviceService.UpdatePolicy(ctx, &application.UpdateDevicePolicyRequest{
+		TenantID:          tenantID,
+		TrustEnabled:      req.TrustEnabled,
+		TrustDays:         req.TrustDays,
+		MaxTrustedDevices: req.MaxTrustedDevices,
+		ReuseThreshold:    req.ReuseThreshold,
+		UpdatedBy:         actorID,
+	})
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// ListUserDevices lista os dispositivos de um usuário, incluindo os revogados
+func (h *RoleHandler) ListUserDevices(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListUserDevices")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	devices, err := h.deviceService.ListDevices(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, tenantID, err)
+		return
+	}
+	if devices == nil {
+		devices = []*model.Device{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, devices)
+}
+
+// RenameUserDevice altera o nome apresentado de um dispositivo do usuário
+func (h *RoleHandler) RenameUserDevice(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RenameUserDevice")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	req, ok := h.deviceActionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var body DeviceRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	device, err := h.deviceService.RenameDevice(ctx, req, body.Name)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, req.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, device)
+}
+
+// TrustUserDevice marca um dispositivo do usuário como de confiança pela validade da política
+func (h *RoleHandler) TrustUserDevice(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.TrustUserDevice")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	req, ok := h.deviceActionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	device, err := h.deviceService.TrustDevice(ctx, req)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, req.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, device)
+}
+
+// UntrustUserDevice retira a confiança num dispositivo do usuário
+func (h *RoleHandler) UntrustUserDevice(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UntrustUserDevice")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	req, ok := h.deviceActionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	device, err := h.deviceService.UntrustDevice(ctx, req)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, req.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, device)
+}
+
+// RevokeUserDevice revoga um dispositivo do usuário e retira-lhe a confiança
+func (h *RoleHandler) RevokeUserDevice(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RevokeUserDevice")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	req, ok := h.deviceActionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	device, err := h.deviceService.RevokeDevice(ctx, req)
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, req.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, device)
+}
+
+// CheckUserDeviceTrust indica se um login do usuário a partir do dispositivo pode dispensar o segundo fator
+func (h *RoleHandler) CheckUserDeviceTrust(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CheckUserDeviceTrust")
+	defer span.End()
+
+	if !h.devicesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+
+	var req DeviceTrustCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.UserAgent == "" {
+		req.UserAgent = r.UserAgent()
+	}
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	decision, err := h.deviceService.EvaluateDeviceTrust(ctx, &model.LoginEvent{
+		TenantID:  tenantID,
+		UserID:    userID,
+		DeviceID:  req.DeviceID,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		h.respondWithDeviceError(w, r, span, tenantID, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Bool("device.trusted", decision.Trusted),
+		attribute.String("device.trust_reason", decision.Reason),
+	)
+	h.respondWithJSON(w, http.StatusOK, decision)
+}
+
+// deviceActionRequest lê o usuário e o dispositivo do caminho; o ator é o usuário autenticado
+func (h *RoleHandler) deviceActionRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (*application.DeviceActionRequest, bool) {
+	vars := mux.Vars(r)
+	userID, err := uuid.Parse(vars["userId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return nil, false
+	}
+	deviceID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidDeviceID, nil)
+		return nil, false
+	}
+
+	req := &application.DeviceActionRequest{
+		TenantID: h.getTenantID(r),
+		UserID:   userID,
+		DeviceID: deviceID,
+		ActorID:  h.getUserID(r),
+	}
+	span.SetAttributes(
+		attribute.String("tenant.id", req.TenantID.String()),
+		attribute.String("user.id", req.UserID.String()),
+		attribute.String("device.id", req.DeviceID.String()),
+		attribute.String("actor.id", req.ActorID.String()),
+	)
+	return req, true
+}
+
+// devicesEnabled responde 501 quando o registo de dispositivos não está configurado
+func (h *RoleHandler) devicesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.deviceService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// respondWithDeviceError mapeia os erros do registo de dispositivos para códigos HTTP apropriados
+func (h *RoleHandler) respondWithDeviceError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar dispositivos")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrDeviceNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidDevice),
+		errors.Is(err, application.ErrInvalidDevicePolicy),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrDeviceTrustDisabled),
+		errors.Is(err, application.ErrTrustedDeviceLimit),
+		errors.Is(err, application.ErrDeviceTrustForbidden),
+		errors.Is(err, application.ErrDeviceRevoked):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeOperationNotAllowed, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar dispositivos")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// EmergencyAccountRequest representa o registo de um usuário do tenant como conta de emergência
+type EmergencyAccountRequest struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+}
+
+// EmergencyActivationRequest representa o pedido de ativação de uma conta de emergência
+// Sem duração, é usada a duração padrão do serviço
+type EmergencyActivationRequest struct {
+	AccountID       uuid.UUID `json:"account_id"`
+	IncidentRef     string    `json:"incident_ref"`
+	Justification   string    `json:"justification"`
+	DurationMinutes int       `json:"duration_minutes,omitempty"`
+}
+
+// EmergencyActivationReasonRequest indica o motivo da recusa ou do fim antecipado de uma ativação
+type EmergencyActivationReasonRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// EmergencyActivationReviewRequest representa a revisão de uma ativação terminada
+type EmergencyActivationReviewRequest struct {
+	Outcome model.EmergencyReviewOutcome `json:"outcome"`
+	Notes   string                       `json:"notes,omitempty"`
+}
+
+// SetEmergencyAccessService configura o serviço das contas de emergência usado pelo handler
+func (h *RoleHandler) SetEmergencyAccessService(emergencyAccessService application.EmergencyAccessService) {
+	h.emergencyAccessService = emergencyAccessService
+}
+
+// ListEmergencyAccounts lista as contas de emergência do tenant
+func (h *RoleHandler) ListEmergencyAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListEmergencyAccounts")
+	defer span.End()
+
+	if !h.emergencyAccessEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	accounts, err := h.emergencyAccessService.ListAccounts(ctx, tenantID)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accounts)
+}
+
+// ProvisionEmergencyAccount regista um usuário do tenant como conta de emergência selada
+func (h *RoleHandler) ProvisionEmergencyAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ProvisionEmergencyAccount")
+	defer span.End()
+
+	if !h.emergencyAccessEnabled(w, r) {
+		return
+	}
+
+	var req EmergencyAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.UserID == uuid.Nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("user.id", req.UserID.String()),
+	)
+
+	account, err := h.emergencyAccessService.ProvisionAccount(ctx, &application.ProvisionEmergencyAccountRequest{
+		TenantID:    tenantID,
+		UserID:      req.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+		ActorID:     actorID,
+	})
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, account)
+}
+
+// ListEmergencyActivations lista as ativações das contas de emergência; status filtra por estado
+func (h *RoleHandler) ListEmergencyActivations(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListEmergencyActivations")
+	defer span.End()
+
+	if !h.emergencyAccessEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	status := model.EmergencyActivationStatus(r.URL.Query().Get("status"))
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(status)),
+	)
+
+	activations, err := h.emergencyAccessService.ListActivations(ctx, tenantID, status)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activations)
+}
+
+// RequestEmergencyActivation pede a ativação de uma conta de emergência
+func (h *RoleHandler) RequestEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RequestEmergencyActivation")
+	defer span.End()
+
+	if !h.emergencyAccessEnabled(w, r) {
+		return
+	}
+
+	var req EmergencyActivationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DurationMinutes < 0 {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("emergency_account.id", req.AccountID.String()),
+	)
+
+	activation, err := h.emergencyAccessService.RequestActivation(ctx, &application.RequestEmergencyActivationRequest{
+		TenantID:      tenantID,
+		AccountID:     req.AccountID,
+		IncidentRef:   req.IncidentRef,
+		Justification: req.Justification,
+		Duration:      time.Duration(req.DurationMinutes) * time.Minute,
+		RequestedBy:   actorID,
+	})
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, activation)
+}
+
+// GetEmergencyActivation obtém uma ativação de conta de emergência
+func (h *RoleHandler) GetEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetEmergencyActivation")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	activation, err := h.emergencyAccessService.GetActivation(ctx, tenantID, activationID)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activation)
+}
+
+// ApproveEmergencyActivation aprova um pedido de ativação; o aprovador tem de ser diferente do requerente
+func (h *RoleHandler) ApproveEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ApproveEmergencyActivation")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	activation, err := h.emergencyAccessService.ApproveActivation(ctx, tenantID, activationID, actorID)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activation)
+}
+
+// RejectEmergencyActivation recusa um pedido de ativação pendente
+func (h *RoleHandler) RejectEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RejectEmergencyActivation")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req EmergencyActivationReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	activation, err := h.emergencyAccessService.RejectActivation(ctx, tenantID, activationID, actorID, req.Reason)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activation)
+}
+
+// CloseEmergencyActivation termina uma ativação antes de expirar
+func (h *RoleHandler) CloseEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CloseEmergencyActivation")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req EmergencyActivationReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	activation, err := h.emergencyAccessService.CloseActivation(ctx, tenantID, activationID, actorID, req.Reason)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activation)
+}
+
+// ReviewEmergencyActivation regista a revisão de uma ativação terminada
+func (h *RoleHandler) ReviewEmergencyActivation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ReviewEmergencyActivation")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req EmergencyActivationReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("emergency_activation.outcome", string(req.Outcome)),
+	)
+
+	activation, err := h.emergencyAccessService.ReviewActivation(ctx, &application.ReviewEmergencyActivationRequest{
+		TenantID:     tenantID,
+		ActivationID: activationID,
+		Outcome:      req.Outcome,
+		Notes:        req.Notes,
+		ReviewerID:   actorID,
+	})
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, activation)
+}
+
+// ListEmergencyActions lista as ações gravadas durante uma ativação, por ordem da sequência
+func (h *RoleHandler) ListEmergencyActions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListEmergencyActions")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var after int64
+	if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+		if parsed, err := strconv.ParseInt(afterStr, 10, 64); err == nil && parsed > 0 {
+			after = parsed
+		}
+	}
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	actions, err := h.emergencyAccessService.ListActions(ctx, tenantID, activationID, after, limit)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, actions)
+}
+
+// VerifyEmergencyActions verifica a integridade do registo encadeado das ações de uma ativação
+func (h *RoleHandler) VerifyEmergencyActions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.VerifyEmergencyActions")
+	defer span.End()
+
+	tenantID, activationID, ok := h.emergencyActivationRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	verification, err := h.emergencyAccessService.VerifyActions(ctx, tenantID, activationID)
+	if err != nil {
+		h.respondWithEmergencyAccessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, verification)
+}
+
+// emergencyAccessEnabled responde 501 quando as contas de emergência não estão configuradas
+func (h *RoleHandler) emergencyAccessEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.emergencyAccessService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// emergencyActivationRequest valida a disponibilidade do serviço e extrai o tenant e a ativação
+func (h *RoleHandler) emergencyActivationRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.emergencyAccessEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	activationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidEmergencyActivationID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("emergency_activation.id", activationID.String()),
+	)
+	return tenantID, activationID, true
+}
+
+// respondWithEmergencyAccessError mapeia os erros das contas de emergência para códigos HTTP apropriados
+func (h *RoleHandler) respondWithEmergencyAccessError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar conta de emergência")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrEmergencyAccountNotFound), errors.Is(err, application.ErrEmergencyActivationNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidEmergencyAccount),
+		errors.Is(err, application.ErrInvalidEmergencyActivation),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrEmergencyDualControl):
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+	case errors.Is(err, application.ErrEmergencyAccountConflict), errors.Is(err, application.ErrEmergencyActivationOpen):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrEmergencyActivationState):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeOperationNotAllowed, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar conta de emergência")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
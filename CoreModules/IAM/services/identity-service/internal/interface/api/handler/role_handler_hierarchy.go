@@ -9,8 +9,15 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
 )
 
+// RoleWithDepthResponse representa uma função da hierarquia com a sua distância à função consultada
+type RoleWithDepthResponse struct {
+	Role  RoleResponse `json:"role"`
+	Depth int          `json:"depth"`
+}
+
 // GetChildRoles obtém as funções filhas diretas de uma função
 func (h *RoleHandler) GetChildRoles(w http.ResponseWriter, r *http.Request) {
 	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetChildRoles")
@@ -20,7 +27,7 @@ func (h *RoleHandler) GetChildRoles(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -41,17 +48,22 @@ func (h *RoleHandler) GetChildRoles(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter funções filhas")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Calcular total de páginas
 	totalPages := totalCount / int64(pagination.PageSize)
 	if totalCount%int64(pagination.PageSize) > 0 {
@@ -60,7 +72,7 @@ func (h *RoleHandler) GetChildRoles(w http.ResponseWriter, r *http.Request) {
 
 	// Responder com a lista de funções filhas e informações de paginação
 	h.respondWithJSON(w, http.StatusOK, response{
-		Data: toRoleResponseList(childRoles),
+		Data: toRoleResponseList(childRoles, access),
 		Pagination: &paginationResponse{
 			Page:       pagination.Page,
 			PageSize:   pagination.PageSize,
@@ -79,7 +91,7 @@ func (h *RoleHandler) GetParentRoles(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -100,17 +112,22 @@ func (h *RoleHandler) GetParentRoles(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter funções pais")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Calcular total de páginas
 	totalPages := totalCount / int64(pagination.PageSize)
 	if totalCount%int64(pagination.PageSize) > 0 {
@@ -119,7 +136,7 @@ func (h *RoleHandler) GetParentRoles(w http.ResponseWriter, r *http.Request) {
 
 	// Responder com a lista de funções pais e informações de paginação
 	h.respondWithJSON(w, http.StatusOK, response{
-		Data: toRoleResponseList(parentRoles),
+		Data: toRoleResponseList(parentRoles, access),
 		Pagination: &paginationResponse{
 			Page:       pagination.Page,
 			PageSize:   pagination.PageSize,
@@ -138,7 +155,7 @@ func (h *RoleHandler) GetDescendantRoles(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -158,29 +175,29 @@ func (h *RoleHandler) GetDescendantRoles(w http.ResponseWriter, r *http.Request)
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter funções descendentes")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Se includeDepth for true, precisamos formatar a resposta para incluir a profundidade
 	if includeDepth {
-		type roleWithDepth struct {
-			Role  RoleResponse `json:"role"`
-			Depth int          `json:"depth"`
-		}
-
 		// Formatar os resultados para incluir a profundidade
-		rolesWithDepth := make([]roleWithDepth, len(descendantRoles))
+		rolesWithDepth := make([]RoleWithDepthResponse, len(descendantRoles))
 		for i, descendant := range descendantRoles {
-			rolesWithDepth[i] = roleWithDepth{
-				Role:  toRoleResponse(descendant.Role),
+			rolesWithDepth[i] = RoleWithDepthResponse{
+				Role:  toRoleResponse(descendant.Role, access),
 				Depth: descendant.Depth,
 			}
 		}
@@ -195,7 +212,7 @@ func (h *RoleHandler) GetDescendantRoles(w http.ResponseWriter, r *http.Request)
 		roles[i] = descendant.Role
 	}
 
-	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles, access))
 }
 
 // GetAncestorRoles obtém todas as funções ancestrais de uma função
@@ -207,7 +224,7 @@ func (h *RoleHandler) GetAncestorRoles(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -227,29 +244,29 @@ func (h *RoleHandler) GetAncestorRoles(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter funções ancestrais")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Se includeDepth for true, precisamos formatar a resposta para incluir a profundidade
 	if includeDepth {
-		type roleWithDepth struct {
-			Role  RoleResponse `json:"role"`
-			Depth int          `json:"depth"`
-		}
-
 		// Formatar os resultados para incluir a profundidade
-		rolesWithDepth := make([]roleWithDepth, len(ancestorRoles))
+		rolesWithDepth := make([]RoleWithDepthResponse, len(ancestorRoles))
 		for i, ancestor := range ancestorRoles {
-			rolesWithDepth[i] = roleWithDepth{
-				Role:  toRoleResponse(ancestor.Role),
+			rolesWithDepth[i] = RoleWithDepthResponse{
+				Role:  toRoleResponse(ancestor.Role, access),
 				Depth: ancestor.Depth,
 			}
 		}
@@ -264,7 +281,7 @@ func (h *RoleHandler) GetAncestorRoles(w http.ResponseWriter, r *http.Request) {
 		roles[i] = ancestor.Role
 	}
 
-	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles, access))
 }
 
 // AssignChildRole atribui uma função filha a uma função pai
@@ -278,13 +295,13 @@ func (h *RoleHandler) AssignChildRole(w http.ResponseWriter, r *http.Request) {
 	
 	parentID, err := uuid.Parse(vars["parentId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_parent_id", "ID da função pai inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidParentID, nil)
 		return
 	}
 	
 	childID, err := uuid.Parse(vars["childId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_child_id", "ID da função filha inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidChildID, nil)
 		return
 	}
 
@@ -300,25 +317,30 @@ func (h *RoleHandler) AssignChildRole(w http.ResponseWriter, r *http.Request) {
 		span.SetStatus(codes.Error, "Falha ao atribuir função filha")
 		span.RecordError(err)
 
+		// Uma concessão fora dos limites de permissões já foi registada como incidente de segurança
+		if h.respondWithPermissionBoundaryViolation(w, r, err) {
+			return
+		}
+
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.CyclicReferenceError:
-			h.respondWithError(w, http.StatusBadRequest, "cyclic_reference", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeCyclicReference, err)
 		case *application.IncompatibleTypesError:
-			h.respondWithError(w, http.StatusBadRequest, "incompatible_types", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeIncompatibleTypes, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("parent_id", parentID.String()).
 				Str("child_id", childID.String()).
 				Msg("Erro ao atribuir função filha")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -338,13 +360,13 @@ func (h *RoleHandler) RemoveChildRole(w http.ResponseWriter, r *http.Request) {
 	
 	parentID, err := uuid.Parse(vars["parentId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_parent_id", "ID da função pai inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidParentID, nil)
 		return
 	}
 	
 	childID, err := uuid.Parse(vars["childId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_child_id", "ID da função filha inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidChildID, nil)
 		return
 	}
 
@@ -363,14 +385,14 @@ func (h *RoleHandler) RemoveChildRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("parent_id", parentID.String()).
 				Str("child_id", childID.String()).
 				Msg("Erro ao remover função filha")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// SetRoleHistoryService configura o serviço de histórico de funções usado pelo handler
+func (h *RoleHandler) SetRoleHistoryService(historyService application.RoleHistoryService) {
+	h.historyService = historyService
+}
+
+// GetRoleHistory lista os eventos imutáveis registados para uma função
+func (h *RoleHandler) GetRoleHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRoleHistory")
+	defer span.End()
+
+	tenantID, roleID, ok := h.historyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	events, err := h.historyService.GetRoleHistory(ctx, tenantID, roleID)
+	if err != nil {
+		h.respondWithHistoryError(w, r, span, tenantID, roleID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, events)
+}
+
+// GetRoleAt reconstrói o estado de uma função no instante indicado pelo parâmetro timestamp
+func (h *RoleHandler) GetRoleAt(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRoleAt")
+	defer span.End()
+
+	tenantID, roleID, ok := h.historyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("timestamp"))
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+		return
+	}
+	span.SetAttributes(attribute.String("history.at", at.Format(time.RFC3339)))
+
+	state, err := h.historyService.GetRoleAt(ctx, tenantID, roleID, at)
+	if err != nil {
+		h.respondWithHistoryError(w, r, span, tenantID, roleID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, state)
+}
+
+// DiffRole compara o estado de uma função entre os instantes indicados pelos parâmetros from e to
+func (h *RoleHandler) DiffRole(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DiffRole")
+	defer span.End()
+
+	tenantID, roleID, ok := h.historyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+		return
+	}
+	span.SetAttributes(
+		attribute.String("history.from", from.Format(time.RFC3339)),
+		attribute.String("history.to", to.Format(time.RFC3339)),
+	)
+
+	diff, err := h.historyService.DiffRole(ctx, tenantID, roleID, from, to)
+	if err != nil {
+		h.respondWithHistoryError(w, r, span, tenantID, roleID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, diff)
+}
+
+// historyRequest valida a disponibilidade do serviço de histórico e extrai o tenant e a função
+func (h *RoleHandler) historyRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if h.historyService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	roleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("role.id", roleID.String()),
+	)
+	return tenantID, roleID, true
+}
+
+// respondWithHistoryError mapeia os erros do histórico para códigos HTTP apropriados
+func (h *RoleHandler) respondWithHistoryError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID, roleID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao consultar histórico da função")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrRoleHistoryNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidHistoryRange):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("role_id", roleID.String()).
+			Msg("Erro ao consultar histórico da função")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
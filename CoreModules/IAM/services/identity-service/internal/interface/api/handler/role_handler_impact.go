@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// ImpactAnalysisRequest representa o modelo de dados de uma alteração proposta para análise de impacto
+type ImpactAnalysisRequest struct {
+	Mutation     string     `json:"mutation"`
+	PermissionID *uuid.UUID `json:"permissionId,omitempty"`
+	ChildRoleID  *uuid.UUID `json:"childRoleId,omitempty"`
+}
+
+// SetImpactAnalysisService configura o serviço de análise de impacto usado pelo handler
+func (h *RoleHandler) SetImpactAnalysisService(impactService application.ImpactAnalysisService) {
+	h.impactService = impactService
+}
+
+// AnalyzeImpact calcula o impacto de uma alteração proposta a uma função sem aplicá-la
+func (h *RoleHandler) AnalyzeImpact(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.AnalyzeImpact")
+	defer span.End()
+
+	if h.impactService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	vars := mux.Vars(r)
+	roleID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
+		return
+	}
+
+	var req ImpactAnalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("role.id", roleID.String()),
+		attribute.String("impact.mutation", req.Mutation),
+	)
+
+	report, err := h.impactService.AnalyzeImpact(ctx, application.ImpactAnalysisRequest{
+		TenantID:     tenantID,
+		RoleID:       roleID,
+		Mutation:     application.MutationType(req.Mutation),
+		PermissionID: req.PermissionID,
+		ChildRoleID:  req.ChildRoleID,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, "Falha ao analisar impacto da alteração")
+		span.RecordError(err)
+
+		// Mapear erros específicos do domínio para códigos HTTP apropriados
+		switch {
+		case errors.Is(err, application.ErrInvalidMutation):
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+		case errors.Is(err, application.ErrRoleNotFound), errors.Is(err, application.ErrChildRoleNotFound):
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+		case errors.Is(err, application.ErrPermissionNotAssigned), errors.Is(err, application.ErrChildRoleNotAssigned):
+			h.respondWithError(w, r, http.StatusUnprocessableEntity, i18n.CodeNotAssigned, err)
+		default:
+			var notFound *application.ResourceNotFoundError
+			if errors.As(err, &notFound) {
+				h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+				return
+			}
+			h.logger.Error().Err(err).
+				Str("tenant_id", tenantID.String()).
+				Str("role_id", roleID.String()).
+				Msg("Erro ao analisar impacto da alteração")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// LegalDocumentRequest representa a publicação de uma nova versão de um documento legal
+// Sem effective_at, a versão entra em vigor no momento da publicação
+type LegalDocumentRequest struct {
+	Type        model.LegalDocumentType `json:"type"`
+	Version     string                  `json:"version"`
+	Title       string                  `json:"title"`
+	URL         string                  `json:"url"`
+	ContentHash string                  `json:"content_hash"`
+	Required    bool                    `json:"required"`
+	EffectiveAt *time.Time              `json:"effective_at,omitempty"`
+}
+
+// LegalAcceptanceRequest indica o hash do conteúdo apresentado ao usuário
+type LegalAcceptanceRequest struct {
+	DocumentHash string `json:"document_hash"`
+}
+
+// SetLegalConsentService configura o serviço de aceitação de documentos legais usado pelo handler
+func (h *RoleHandler) SetLegalConsentService(legalConsentService application.LegalConsentService) {
+	h.legalConsentService = legalConsentService
+}
+
+// ListLegalDocuments lista as versões publicadas pelo tenant ou, com current=true, as versões em vigor
+func (h *RoleHandler) ListLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListLegalDocuments")
+	defer span.End()
+
+	if !h.legalConsentEnabled(w, r) {
+		return
+	}
+
+	currentOnly := false
+	if value := r.URL.Query().Get("current"); value != "" {
+		var err error
+		if currentOnly, err = strconv.ParseBool(value); err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Bool("legal_document.current_only", currentOnly),
+	)
+
+	documents, err := h.legalConsentService.ListDocuments(ctx, tenantID, currentOnly)
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, documents)
+}
+
+// PublishLegalDocument publica uma nova versão de um documento legal do tenant
+func (h *RoleHandler) PublishLegalDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.PublishLegalDocument")
+	defer span.End()
+
+	if !h.legalConsentEnabled(w, r) {
+		return
+	}
+
+	var req LegalDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("legal_document.type", string(req.Type)),
+		attribute.String("legal_document.version", req.Version),
+	)
+
+	document, err := h.legalConsentService.PublishDocument(ctx, &application.PublishLegalDocumentRequest{
+		TenantID:    tenantID,
+		Type:        req.Type,
+		Version:     req.Version,
+		Title:       req.Title,
+		URL:         req.URL,
+		ContentHash: req.ContentHash,
+		Required:    req.Required,
+		EffectiveAt: req.EffectiveAt,
+		PublishedBy: h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, document)
+}
+
+// ListPendingLegalDocuments lista as versões em vigor que o usuário autenticado ainda não aceitou
+func (h *RoleHandler) ListPendingLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListPendingLegalDocuments")
+	defer span.End()
+
+	if !h.legalConsentEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	documents, err := h.legalConsentService.ListPendingDocuments(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, documents)
+}
+
+// GetLegalDocument obtém uma versão de um documento legal do tenant
+func (h *RoleHandler) GetLegalDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetLegalDocument")
+	defer span.End()
+
+	tenantID, documentID, ok := h.legalDocumentRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	document, err := h.legalConsentService.GetDocument(ctx, tenantID, documentID)
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, document)
+}
+
+// AcceptLegalDocument regista a aceitação de um documento pelo usuário autenticado
+func (h *RoleHandler) AcceptLegalDocument(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.AcceptLegalDocument")
+	defer span.End()
+
+	tenantID, documentID, ok := h.legalDocumentRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req LegalAcceptanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DocumentHash == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	userID := h.getUserID(r)
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	acceptance, err := h.legalConsentService.Accept(ctx, &application.AcceptLegalDocumentRequest{
+		TenantID:     tenantID,
+		UserID:       userID,
+		DocumentID:   documentID,
+		DocumentHash: req.DocumentHash,
+		IPAddress:    h.clientIP(r),
+		UserAgent:    r.UserAgent(),
+	})
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, acceptance)
+}
+
+// GetLegalAcceptanceReport resume a aceitação de uma versão de um documento pelos usuários ativos
+func (h *RoleHandler) GetLegalAcceptanceReport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetLegalAcceptanceReport")
+	defer span.End()
+
+	tenantID, documentID, ok := h.legalDocumentRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	report, err := h.legalConsentService.AcceptanceReport(ctx, tenantID, documentID)
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
+
+// ListLegalAcceptances lista os registos de aceitação do tenant
+// Filtros opcionais: user_id, document_id, since (RFC 3339) e limit
+func (h *RoleHandler) ListLegalAcceptances(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListLegalAcceptances")
+	defer span.End()
+
+	if !h.legalConsentEnabled(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	var filter model.LegalAcceptanceFilter
+	if value := query.Get("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+			return
+		}
+		filter.UserID = &userID
+	}
+	if value := query.Get("document_id"); value != "" {
+		documentID, err := uuid.Parse(value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidLegalDocumentID, nil)
+			return
+		}
+		filter.DocumentID = &documentID
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	acceptances, err := h.legalConsentService.ListAcceptances(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithLegalConsentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, acceptances)
+}
+
+// legalConsentEnabled responde 501 quando a aceitação de documentos legais não está configurada
+func (h *RoleHandler) legalConsentEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.legalConsentService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// legalDocumentRequest valida a disponibilidade do serviço e extrai o tenant e o documento
+func (h *RoleHandler) legalDocumentRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.legalConsentEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	documentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidLegalDocumentID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("legal_document.id", documentID.String()),
+	)
+	return tenantID, documentID, true
+}
+
+// respondWithLegalConsentError mapeia os erros da aceitação de documentos legais para códigos HTTP apropriados
+func (h *RoleHandler) respondWithLegalConsentError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar documento legal")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrLegalDocumentNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidLegalDocument), errors.Is(err, application.ErrInvalidLegalAcceptanceFilter),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrLegalDocumentVersionExists):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrLegalDocumentHashMismatch):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeOperationNotAllowed, err)
+	case errors.Is(err, application.ErrLegalDocumentNotCurrent):
+		h.respondWithError(w, r, http.StatusUnprocessableEntity, i18n.CodeOperationNotAllowed, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar documento legal")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
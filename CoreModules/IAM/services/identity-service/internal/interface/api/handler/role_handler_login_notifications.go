@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// LoginNotificationSettingsRequest representa a configuração das notificações de login do tenant autenticado
+// Sem mercado no corpo, é usado o cabeçalho X-Market
+type LoginNotificationSettingsRequest struct {
+	Enabled          bool                             `json:"enabled"`
+	Channels         []model.LoginNotificationChannel `json:"channels"`
+	Market           string                           `json:"market,omitempty"`
+	DefaultLocale    string                           `json:"default_locale,omitempty"`
+	NotifyFirstLogin bool                             `json:"notify_first_login"`
+}
+
+// LoginNotificationTemplateRequest representa a personalização de uma mensagem por canal, mercado e idioma
+type LoginNotificationTemplateRequest struct {
+	Kind    model.LoginNotificationKind    `json:"kind"`
+	Channel model.LoginNotificationChannel `json:"channel"`
+	Market  string                         `json:"market,omitempty"`
+	Locale  string                         `json:"locale"`
+	Subject string                         `json:"subject,omitempty"`
+	Body    string                         `json:"body"`
+}
+
+// LoginNotMeRequest representa a resposta "não fui eu" com o token do link da notificação
+type LoginNotMeRequest struct {
+	Token string `json:"token"`
+}
+
+// SetLoginNotificationService configura o serviço das notificações de login usado pelo handler
+func (h *RoleHandler) SetLoginNotificationService(loginNotificationService application.LoginNotificationService) {
+	h.loginNotificationService = loginNotificationService
+}
+
+// GetLoginNotificationSettings obtém a configuração das notificações de login do tenant
+func (h *RoleHandler) GetLoginNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetLoginNotificationSettings")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	settings, err := h.loginNotificationService.GetSettings(ctx, tenantID)
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, settings)
+}
+
+// UpdateLoginNotificationSettings altera a configuração das notificações de login do tenant
+func (h *RoleHandler) UpdateLoginNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateLoginNotificationSettings")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	var req LoginNotificationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.Market == "" {
+		req.Market = r.Header.Get("X-Market")
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.Bool("login_notification.enabled", req.Enabled),
+	)
+
+	settings, err := h.loginNotificationService.UpdateSettings(ctx, &application.UpdateLoginNotificationSettingsRequest{
+		TenantID:         tenantID,
+		Enabled:          req.Enabled,
+		Channels:         req.Channels,
+		Market:           req.Market,
+		DefaultLocale:    req.DefaultLocale,
+		NotifyFirstLogin: req.NotifyFirstLogin,
+		UpdatedBy:        actorID,
+	})
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, settings)
+}
+
+// ListLoginNotificationTemplates lista os modelos de mensagem personalizados do tenant
+func (h *RoleHandler) ListLoginNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListLoginNotificationTemplates")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	templates, err := h.loginNotificationService.ListTemplates(ctx, tenantID)
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+	if templates == nil {
+		templates = []*model.LoginNotificationTemplate{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, templates)
+}
+
+// SaveLoginNotificationTemplate grava um modelo de mensagem, substituindo o da mesma mensagem,
+// canal, mercado e idioma
+func (h *RoleHandler) SaveLoginNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.SaveLoginNotificationTemplate")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	var req LoginNotificationTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("login_notification.kind", string(req.Kind)),
+		attribute.String("login_notification.channel", string(req.Channel)),
+	)
+
+	template, err := h.loginNotificationService.SaveTemplate(ctx, &application.SaveLoginNotificationTemplateRequest{
+		TenantID:  tenantID,
+		Kind:      req.Kind,
+		Channel:   req.Channel,
+		Market:    req.Market,
+		Locale:    req.Locale,
+		Subject:   req.Subject,
+		Body:      req.Body,
+		UpdatedBy: actorID,
+	})
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// DeleteLoginNotificationTemplate remove um modelo; a mensagem volta a usar o modelo padrão
+func (h *RoleHandler) DeleteLoginNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DeleteLoginNotificationTemplate")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	templateID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidNotificationTemplateID, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("login_notification.template_id", templateID.String()),
+	)
+
+	if err := h.loginNotificationService.DeleteTemplate(ctx, tenantID, templateID, actorID); err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUserLoginNotifications lista as notificações de login mais recentes enviadas a um usuário
+func (h *RoleHandler) ListUserLoginNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListUserLoginNotifications")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	userID, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+
+	notifications, err := h.loginNotificationService.ListNotifications(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, notifications)
+}
+
+// RespondLoginNotification trata a resposta "não fui eu" feita a partir do link da notificação:
+// termina as sessões do usuário e envia-lhe o link de redefinição da credencial
+// O pedido não é autenticado; o tenant é indicado no cabeçalho X-Tenant-ID
+func (h *RoleHandler) RespondLoginNotification(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RespondLoginNotification")
+	defer span.End()
+
+	if !h.loginNotificationsEnabled(w, r) {
+		return
+	}
+
+	var req LoginNotMeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	result, err := h.loginNotificationService.RespondNotMe(ctx, tenantID, req.Token, h.clientIP(r))
+	if err != nil {
+		h.respondWithLoginNotificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("login_notification.id", result.NotificationID.String()),
+		attribute.Int("login_notification.sessions_ended", result.SessionsEnded),
+	)
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+// loginNotificationsEnabled responde 501 quando as notificações de login não estão configuradas
+func (h *RoleHandler) loginNotificationsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.loginNotificationService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// respondWithLoginNotificationError mapeia os erros das notificações de login para códigos HTTP apropriados
+func (h *RoleHandler) respondWithLoginNotificationError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar notificações de login")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrInvalidLoginResponseToken):
+		h.respondWithError(w, r, http.StatusUnauthorized, i18n.CodeInvalidLoginResponseToken, nil)
+	case errors.Is(err, application.ErrLoginNotificationTemplateNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidLoginNotificationSettings),
+		errors.Is(err, application.ErrInvalidLoginNotificationTemplate),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar notificações de login")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// NetworkPolicyRequest representa a criação ou a alteração de uma política de rede do tenant
+type NetworkPolicyRequest struct {
+	Name     string                    `json:"name"`
+	Scope    model.NetworkPolicyScope  `json:"scope"`
+	Action   model.NetworkPolicyAction `json:"action"`
+	RoleCode string                    `json:"role_code,omitempty"`
+	CIDRs    []string                  `json:"cidrs"`
+	Enabled  bool                      `json:"enabled"`
+}
+
+// BreakGlassRequest representa a ativação de um acesso de emergência
+// Sem duração, é usada a duração padrão do serviço
+type BreakGlassRequest struct {
+	UserID          uuid.UUID `json:"user_id"`
+	Justification   string    `json:"justification"`
+	TicketRef       string    `json:"ticket_ref,omitempty"`
+	DurationMinutes int       `json:"duration_minutes,omitempty"`
+}
+
+// SetNetworkPolicyService configura o serviço de políticas de rede usado pelo handler
+func (h *RoleHandler) SetNetworkPolicyService(networkPolicyService application.NetworkPolicyService) {
+	h.networkPolicyService = networkPolicyService
+}
+
+// SetTrustedProxies configura os blocos CIDR dos proxies de confiança, cujo X-Forwarded-For indica o
+// endereço do cliente registado pelos fluxos de autenticação
+func (h *RoleHandler) SetTrustedProxies(cidrs []string) {
+	h.trustedProxies = middleware.ParseTrustedProxies(h.logger, cidrs)
+}
+
+// ListNetworkPolicies lista as políticas de rede do tenant
+func (h *RoleHandler) ListNetworkPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListNetworkPolicies")
+	defer span.End()
+
+	if !h.networkPoliciesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	policies, err := h.networkPolicyService.ListPolicies(ctx, tenantID)
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policies)
+}
+
+// CreateNetworkPolicy cria uma política de rede no tenant
+func (h *RoleHandler) CreateNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateNetworkPolicy")
+	defer span.End()
+
+	if !h.networkPoliciesEnabled(w, r) {
+		return
+	}
+
+	var req NetworkPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("network_policy.scope", string(req.Scope)),
+		attribute.String("network_policy.action", string(req.Action)),
+	)
+
+	policy, err := h.networkPolicyService.CreatePolicy(ctx, req.toApplication(tenantID, uuid.Nil, actorID))
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, policy)
+}
+
+// GetNetworkPolicy obtém uma política de rede do tenant
+func (h *RoleHandler) GetNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetNetworkPolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.networkPolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	policy, err := h.networkPolicyService.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// UpdateNetworkPolicy substitui uma política de rede do tenant
+func (h *RoleHandler) UpdateNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateNetworkPolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.networkPolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req NetworkPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	policy, err := h.networkPolicyService.UpdatePolicy(ctx, req.toApplication(tenantID, policyID, actorID))
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// DeleteNetworkPolicy remove uma política de rede do tenant
+func (h *RoleHandler) DeleteNetworkPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DeleteNetworkPolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.networkPolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	if err := h.networkPolicyService.DeletePolicy(ctx, tenantID, policyID, actorID); err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListBreakGlassOverrides lista os acessos de emergência do tenant; active=true limita aos que estão em vigor
+func (h *RoleHandler) ListBreakGlassOverrides(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListBreakGlassOverrides")
+	defer span.End()
+
+	if !h.networkPoliciesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	activeOnly := r.URL.Query().Get("active") == "true"
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.Bool("filter.active", activeOnly),
+	)
+
+	overrides, err := h.networkPolicyService.ListBreakGlass(ctx, tenantID, activeOnly)
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, overrides)
+}
+
+// ActivateBreakGlass ativa um acesso de emergência temporário que ignora as políticas de rede
+func (h *RoleHandler) ActivateBreakGlass(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ActivateBreakGlass")
+	defer span.End()
+
+	if !h.networkPoliciesEnabled(w, r) {
+		return
+	}
+
+	var req BreakGlassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DurationMinutes < 0 {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.UserID == uuid.Nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("user.id", req.UserID.String()),
+	)
+
+	override, err := h.networkPolicyService.ActivateBreakGlass(ctx, &application.ActivateBreakGlassRequest{
+		TenantID:      tenantID,
+		UserID:        req.UserID,
+		Justification: req.Justification,
+		TicketRef:     req.TicketRef,
+		Duration:      time.Duration(req.DurationMinutes) * time.Minute,
+		GrantedBy:     actorID,
+	})
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, override)
+}
+
+// RevokeBreakGlass revoga um acesso de emergência antes de expirar
+func (h *RoleHandler) RevokeBreakGlass(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RevokeBreakGlass")
+	defer span.End()
+
+	tenantID, overrideID, ok := h.breakGlassRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	if err := h.networkPolicyService.RevokeBreakGlass(ctx, tenantID, overrideID, actorID); err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListBreakGlassUses lista os pedidos permitidos por um acesso de emergência
+func (h *RoleHandler) ListBreakGlassUses(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListBreakGlassUses")
+	defer span.End()
+
+	tenantID, overrideID, ok := h.breakGlassRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	uses, err := h.networkPolicyService.ListBreakGlassUses(ctx, tenantID, overrideID, limit)
+	if err != nil {
+		h.respondWithNetworkPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, uses)
+}
+
+// toApplication converte o corpo do pedido no pedido do serviço
+func (req *NetworkPolicyRequest) toApplication(tenantID, policyID, actorID uuid.UUID) *application.SaveNetworkPolicyRequest {
+	return &application.SaveNetworkPolicyRequest{
+		TenantID: tenantID,
+		PolicyID: policyID,
+		Name:     req.Name,
+		Scope:    req.Scope,
+		Action:   req.Action,
+		RoleCode: req.RoleCode,
+		CIDRs:    req.CIDRs,
+		Enabled:  req.Enabled,
+		ActorID:  actorID,
+	}
+}
+
+// networkPoliciesEnabled responde 501 quando as políticas de rede não estão configuradas
+func (h *RoleHandler) networkPoliciesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.networkPolicyService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// networkPolicyRequest valida a disponibilidade do serviço e extrai o tenant e a política
+func (h *RoleHandler) networkPolicyRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.networkPoliciesEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	policyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidNetworkPolicyID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("network_policy.id", policyID.String()),
+	)
+	return tenantID, policyID, true
+}
+
+// breakGlassRequest valida a disponibilidade do serviço e extrai o tenant e o acesso de emergência
+func (h *RoleHandler) breakGlassRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.networkPoliciesEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	overrideID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidBreakGlassID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("break_glass.id", overrideID.String()),
+	)
+	return tenantID, overrideID, true
+}
+
+// respondWithNetworkPolicyError mapeia os erros das políticas de rede para códigos HTTP apropriados
+func (h *RoleHandler) respondWithNetworkPolicyError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar política de rede")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrNetworkPolicyNotFound), errors.Is(err, application.ErrBreakGlassNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidNetworkPolicy),
+		errors.Is(err, application.ErrInvalidBreakGlass),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrNetworkPolicyConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar política de rede")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// PasswordlessSettingsRequest representa a configuração sem senha do tenant autenticado
+// Sem mercado no corpo, é usado o cabeçalho X-Market
+type PasswordlessSettingsRequest struct {
+	Enabled         bool                            `json:"enabled"`
+	Methods         []model.PasswordlessMethod      `json:"methods"`
+	DeviceBinding   model.PasswordlessDeviceBinding `json:"device_binding,omitempty"`
+	Market          string                          `json:"market,omitempty"`
+	FinancialTenant bool                            `json:"financial_tenant"`
+}
+
+// PasswordlessStartRequest representa o pedido de um link mágico ou de um código por email
+// Sem device_id no corpo, é usado o cabeçalho X-Device-ID
+type PasswordlessStartRequest struct {
+	Email    string                   `json:"email"`
+	Method   model.PasswordlessMethod `json:"method"`
+	DeviceID string                   `json:"device_id,omitempty"`
+}
+
+// PasswordlessMagicLinkRequest representa a conclusão do login com o token do link mágico
+type PasswordlessMagicLinkRequest struct {
+	Token    string `json:"token"`
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// PasswordlessOTPRequest representa a conclusão do login com o código enviado por email
+type PasswordlessOTPRequest struct {
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	Code        string    `json:"code"`
+	DeviceID    string    `json:"device_id,omitempty"`
+}
+
+// SetPasswordlessService configura o serviço de autenticação sem senha usado pelo handler
+func (h *RoleHandler) SetPasswordlessService(passwordlessService application.PasswordlessService) {
+	h.passwordlessService = passwordlessService
+}
+
+// GetPasswordlessSettings obtém a configuração sem senha do tenant
+func (h *RoleHandler) GetPasswordlessSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetPasswordlessSettings")
+	defer span.End()
+
+	if !h.passwordlessEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	settings, err := h.passwordlessService.GetSettings(ctx, tenantID)
+	if err != nil {
+		h.respondWithPasswordlessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, settings)
+}
+
+// UpdatePasswordlessSettings altera a configuração sem senha do tenant
+func (h *RoleHandler) UpdatePasswordlessSettings(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdatePasswordlessSettings")
+	defer span.End()
+
+	if !h.passwordlessEnabled(w, r) {
+		return
+	}
+
+	var req PasswordlessSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.Market == "" {
+		req.Market = r.Header.Get("X-Market")
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.Bool("passwordless.enabled", req.Enabled),
+		attribute.String("passwordless.market", req.Market),
+	)
+
+	settings, err := h.passwordlessService.UpdateSettings(ctx, &application.UpdatePasswordlessSettingsRequest{
+		TenantID:        tenantID,
+		Enabled:         req.Enabled,
+		Methods:         req.Methods,
+		DeviceBinding:   req.DeviceBinding,
+		Market:          req.Market,
+		FinancialTenant: req.FinancialTenant,
+		UpdatedBy:       actorID,
+	})
+	if err != nil {
+		h.respondWithPasswordlessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, settings)
+}
+
+// StartPasswordlessLogin envia um link mágico ou um código para o email indicado
+// A resposta é a mesma quer o email tenha conta no tenant quer não
+func (h *RoleHandler) StartPasswordlessLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.StartPasswordlessLogin")
+	defer span.End()
+
+	if !h.passwordlessEnabled(w, r) {
+		return
+	}
+
+	var req PasswordlessStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("passwordless.method", string(req.Method)),
+	)
+
+	issued, err := h.passwordlessService.StartLogin(ctx, &application.StartPasswordlessLoginRequest{
+		TenantID:  tenantID,
+		Email:     req.Email,
+		Method:    req.Method,
+		DeviceID:  passwordlessDeviceID(r, req.DeviceID),
+		IPAddress: h.clientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		h.respondWithPasswordlessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, issued)
+}
+
+// VerifyPasswordlessMagicLink conclui o login com o token do link mágico
+func (h *RoleHandler) VerifyPasswordlessMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.VerifyPasswordlessMagicLink")
+	defer span.End()
+
+	if !h.passwordlessEnabled(w, r) {
+		return
+	}
+
+	var req PasswordlessMagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	result, err := h.passwordlessService.VerifyMagicLink(ctx, &application.VerifyPasswordlessRequest{
+		TenantID:  tenantID,
+		Token:     req.Token,
+		DeviceID:  passwordlessDeviceID(r, req.DeviceID),
+		IPAddress: h.clientIP(r),
+		UserAgent: r.UserAgent(),
+		Country:   passwordlessClientCountry(r),
+	})
+	if err != nil {
+		h.respondWithPasswordlessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithPasswordlessLogin(w, span, result)
+}
+
+// VerifyPasswordlessOTP conclui o login com o código enviado por email
+func (h *RoleHandler) VerifyPasswordlessOTP(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.VerifyPasswordlessOTP")
+	defer span.End()
+
+	if !h.passwordlessEnabled(w, r) {
+		return
+	}
+
+	var req PasswordlessOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeID == uuid.Nil || req.Code == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("passwordless_challenge.id", req.ChallengeID.String()),
+	)
+
+	result, err := h.passwordlessService.VerifyOTP(ctx, &application.VerifyPasswordlessRequest{
+		TenantID:    tenantID,
+		ChallengeID: req.ChallengeID,
+		Code:        req.Code,
+		DeviceID:    passwordlessDeviceID(r, req.DeviceID),
+		IPAddress:   h.clientIP(r),
+		UserAgent:   r.UserAgent(),
+		Country:     passwordlessClientCountry(r),
+	})
+	if err != nil {
+		h.respondWithPasswordlessError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithPasswordlessLogin(w, span, result)
+}
+
+// respondWithPasswordlessLogin responde com o login concluído ou, com documentos legais
+// obrigatórios por aceitar, com 403 e a lista desses documentos
+func (h *RoleHandler) respondWithPasswordlessLogin(w http.ResponseWriter, span trace.Span, result *application.PasswordlessLoginResult) {
+	span.SetAttributes(
+		attribute.String("user.id", result.User.ID.String()),
+		attribute.Bool("passwordless.device_bound", result.DeviceBound),
+	)
+	if len(result.PendingDocuments) > 0 {
+		span.SetAttributes(attribute.Int("legal_document.pending", len(result.PendingDocuments)))
+		h.respondWithJSON(w, http.StatusForbidden, result)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+// passwordlessEnabled responde 501 quando a autenticação sem senha não está configurada
+func (h *RoleHandler) passwordlessEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.passwordlessService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// passwordlessDeviceID retorna o dispositivo indicado no corpo ou, na sua falta, no cabeçalho X-Device-ID
+func passwordlessDeviceID(r *http.Request, deviceID string) string {
+	if deviceID != "" {
+		return deviceID
+	}
+	return r.Header.Get("X-Device-ID")
+}
+
+// clientIP retorna o endereço do cliente; X-Forwarded-For só é considerado nos pedidos dos proxies de confiança
+func (h *RoleHandler) clientIP(r *http.Request) string {
+	return middleware.ClientIP(r, h.trustedProxies)
+}
+
+// passwordlessClientCountry retorna o país do cliente indicado pelo proxy de entrada no cabeçalho X-Client-Country
+func passwordlessClientCountry(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-Client-Country"))
+}
+
+// respondWithPasswordlessError mapeia os erros da autenticação sem senha para códigos HTTP apropriados
+// Os motivos da rejeição de um link ou código não são devolvidos ao cliente
+func (h *RoleHandler) respondWithPasswordlessError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar autenticação sem senha")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrInvalidPasswordlessSettings),
+		errors.Is(err, application.ErrPasswordlessDeviceRequired),
+		errors.Is(err, model.ErrInvalidEmail),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrInvalidPasswordlessToken):
+		h.respondWithError(w, r, http.StatusUnauthorized, i18n.CodeInvalidLoginToken, nil)
+	case errors.Is(err, application.ErrPasswordlessDisabled),
+		errors.Is(err, application.ErrPasswordlessNotAllowed),
+		errors.Is(err, application.ErrPasswordlessMethodNotAllowed):
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+	case errors.Is(err, application.ErrPasswordlessRateLimited):
+		h.respondWithError(w, r, http.StatusTooManyRequests, i18n.CodeTooManyRequests, nil)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar autenticação sem senha")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// PermissionBoundaryRequest representa a criação ou a alteração de um limite de permissões
+// Na alteração, o tipo e o identificador do titular, quando enviados, têm de ser os do limite atual
+type PermissionBoundaryRequest struct {
+	SubjectType model.PermissionBoundarySubjectType `json:"subject_type"`
+	SubjectID   uuid.UUID                           `json:"subject_id"`
+	Name        string                              `json:"name"`
+	Description string                              `json:"description,omitempty"`
+	Permissions []string                            `json:"permissions"`
+}
+
+// SetPermissionBoundaryService configura o serviço dos limites de permissões usado pelo handler
+func (h *RoleHandler) SetPermissionBoundaryService(permissionBoundaryService application.PermissionBoundaryService) {
+	h.permissionBoundaryService = permissionBoundaryService
+}
+
+// ListPermissionBoundaries lista os limites de permissões do tenant
+func (h *RoleHandler) ListPermissionBoundaries(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListPermissionBoundaries")
+	defer span.End()
+
+	if !h.permissionBoundariesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	boundaries, err := h.permissionBoundaryService.ListBoundaries(ctx, tenantID)
+	if err != nil {
+		h.respondWithPermissionBoundaryError(w, r, span, tenantID, err)
+		return
+	}
+	if boundaries == nil {
+		boundaries = []*model.PermissionBoundary{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, boundaries)
+}
+
+// CreatePermissionBoundary cria o limite de permissões de uma função ou de um administrador delegado
+func (h *RoleHandler) CreatePermissionBoundary(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreatePermissionBoundary")
+	defer span.End()
+
+	if !h.permissionBoundariesEnabled(w, r) {
+		return
+	}
+
+	var req PermissionBoundaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("permission_boundary.subject_type", string(req.SubjectType)),
+		attribute.String("permission_boundary.subject_id", req.SubjectID.String()),
+	)
+
+	boundary, err := h.permissionBoundaryService.CreateBoundary(ctx, permissionBoundaryRequest(&req, tenantID, uuid.Nil, actorID))
+	if err != nil {
+		h.respondWithPermissionBoundaryError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, boundary)
+}
+
+// GetPermissionBoundary obtém um limite de permissões do tenant
+func (h *RoleHandler) GetPermissionBoundary(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetPermissionBoundary")
+	defer span.End()
+
+	tenantID, boundaryID, ok := h.permissionBoundaryRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	boundary, err := h.permissionBoundaryService.GetBoundary(ctx, tenantID, boundaryID)
+	if err != nil {
+		h.respondWithPermissionBoundaryError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, boundary)
+}
+
+// UpdatePermissionBoundary altera o nome, a descrição e os padrões de um limite de permissões
+func (h *RoleHandler) UpdatePermissionBoundary(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdatePermissionBoundary")
+	defer span.End()
+
+	tenantID, boundaryID, ok := h.permissionBoundaryRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req PermissionBoundaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	boundary, err := h.permissionBoundaryService.UpdateBoundary(ctx, permissionBoundaryRequest(&req, tenantID, boundaryID, actorID))
+	if err != nil {
+		h.respondWithPermissionBoundaryError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, boundary)
+}
+
+// DeletePermissionBoundary remove um limite de permissões
+func (h *RoleHandler) DeletePermissionBoundary(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DeletePermissionBoundary")
+	defer span.End()
+
+	tenantID, boundaryID, ok := h.permissionBoundaryRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	if err := h.permissionBoundaryService.DeleteBoundary(ctx, tenantID, boundaryID, actorID); err != nil {
+		h.respondWithPermissionBoundaryError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// permissionBoundaryRequest converte o corpo do pedido no pedido do serviço
+func permissionBoundaryRequest(req *PermissionBoundaryRequest, tenantID, boundaryID, actorID uuid.UUID) *application.SavePermissionBoundaryRequest {
+	return &application.SavePermissionBoundaryRequest{
+		TenantID:    tenantID,
+		BoundaryID:  boundaryID,
+		SubjectType: req.SubjectType,
+		SubjectID:   req.SubjectID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		ActorID:     actorID,
+	}
+}
+
+// permissionBoundariesEnabled responde 501 quando os limites de permissões não estão configurados
+func (h *RoleHandler) permissionBoundariesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.permissionBoundaryService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// permissionBoundaryRequest valida a disponibilidade do serviço e extrai o tenant e o limite
+func (h *RoleHandler) permissionBoundaryRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.permissionBoundariesEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	boundaryID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidPermissionBoundaryID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("permission_boundary.id", boundaryID.String()),
+	)
+	return tenantID, boundaryID, true
+}
+
+// respondWithPermissionBoundaryViolation responde 403 quando a operação ultrapassa um limite de permissões
+// Retorna false para os restantes erros, mapeados pelo handler da operação
+func (h *RoleHandler) respondWithPermissionBoundaryViolation(w http.ResponseWriter, r *http.Request, err error) bool {
+	if !errors.Is(err, application.ErrPermissionBoundaryViolation) {
+		return false
+	}
+	h.respondWithError(w, r, http.StatusForbidden, i18n.CodePermissionBoundaryExceeded, err)
+	return true
+}
+
+// respondWithPermissionBoundaryError mapeia os erros dos limites de permissões para códigos HTTP apropriados
+func (h *RoleHandler) respondWithPermissionBoundaryError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar limites de permissões")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrPermissionBoundaryNotFound),
+		errors.Is(err, application.ErrPermissionBoundarySubjectNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidPermissionBoundary),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrPermissionBoundaryConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar limites de permissões")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
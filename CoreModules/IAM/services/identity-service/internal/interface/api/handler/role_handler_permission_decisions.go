@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// Número de decisões de autorização devolvidas por consulta, por omissão e no máximo
+const (
+	defaultPermissionDecisionListLimit = 100
+	maxPermissionDecisionListLimit     = 1000
+)
+
+// SetPermissionDecisionAuditService configura o serviço de auditoria das decisões de autorização usado pelo handler
+func (h *RoleHandler) SetPermissionDecisionAuditService(decisionService application.PermissionDecisionAuditService) {
+	h.decisionService = decisionService
+}
+
+// ListPermissionDecisions consulta as decisões de autorização do tenant por usuário, recurso e período,
+// para responder a "quem acedeu a quê". As permissões são amostradas; sample_rate indica a taxa aplicada
+func (h *RoleHandler) ListPermissionDecisions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListPermissionDecisions")
+	defer span.End()
+
+	if h.decisionService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.PermissionDecisionFilter{
+		Resource: query.Get("resource"),
+		Limit:    defaultPermissionDecisionListLimit,
+	}
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+			return
+		}
+		filter.UserID = &userID
+	}
+	if allowedStr := query.Get("allowed"); allowedStr != "" {
+		allowed, err := strconv.ParseBool(allowedStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		filter.Allowed = &allowed
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+				return
+			}
+			*target = &parsed
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit <= maxPermissionDecisionListLimit {
+			filter.Limit = limit
+		}
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.resource", filter.Resource),
+	)
+
+	decisions, err := h.decisionService.ListDecisions(ctx, tenantID, filter)
+	if err != nil {
+		span.SetStatus(codes.Error, "Falha ao consultar decisões de autorização")
+		span.RecordError(err)
+
+		if errors.Is(err, application.ErrInvalidPermissionDecisionFilter) {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+			return
+		}
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao consultar decisões de autorização")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, decisions)
+}
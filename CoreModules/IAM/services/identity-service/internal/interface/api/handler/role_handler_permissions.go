@@ -10,9 +10,15 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
 	"innovabiz/iam/identity-service/internal/domain/model"
 )
 
+// PermissionCheckResponse representa o resultado da verificação de uma permissão numa função
+type PermissionCheckResponse struct {
+	HasPermission bool `json:"hasPermission"`
+}
+
 // PermissionResponse representa o modelo de dados para retorno de uma permissão
 type PermissionResponse struct {
 	ID          uuid.UUID              `json:"id"`
@@ -74,7 +80,7 @@ func (h *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -95,13 +101,13 @@ func (h *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request)
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter permissões da função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -133,7 +139,7 @@ func (h *RoleHandler) GetAllRolePermissions(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -150,13 +156,13 @@ func (h *RoleHandler) GetAllRolePermissions(w http.ResponseWriter, r *http.Reque
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter todas as permissões da função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -176,13 +182,13 @@ func (h *RoleHandler) AssignPermission(w http.ResponseWriter, r *http.Request) {
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	permissionID, err := uuid.Parse(vars["permissionId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_permission_id", "ID da permissão inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidPermissionID, nil)
 		return
 	}
 
@@ -198,19 +204,24 @@ func (h *RoleHandler) AssignPermission(w http.ResponseWriter, r *http.Request) {
 		span.SetStatus(codes.Error, "Falha ao atribuir permissão")
 		span.RecordError(err)
 
+		// Uma concessão fora dos limites de permissões já foi registada como incidente de segurança
+		if h.respondWithPermissionBoundaryViolation(w, r, err) {
+			return
+		}
+
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Str("permission_id", permissionID.String()).
 				Msg("Erro ao atribuir permissão")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -230,13 +241,13 @@ func (h *RoleHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	permissionID, err := uuid.Parse(vars["permissionId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_permission_id", "ID da permissão inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidPermissionID, nil)
 		return
 	}
 
@@ -255,16 +266,16 @@ func (h *RoleHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Str("permission_id", permissionID.String()).
 				Msg("Erro ao revogar permissão")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -283,13 +294,13 @@ func (h *RoleHandler) CheckPermission(w http.ResponseWriter, r *http.Request) {
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	permissionID, err := uuid.Parse(vars["permissionId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_permission_id", "ID da permissão inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidPermissionID, nil)
 		return
 	}
 
@@ -319,7 +330,7 @@ func (h *RoleHandler) CheckPermission(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch checkErr.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", checkErr.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, checkErr)
 		default:
 			h.logger.Error().Err(checkErr).
 				Str("tenant_id", tenantID.String()).
@@ -327,13 +338,11 @@ func (h *RoleHandler) CheckPermission(w http.ResponseWriter, r *http.Request) {
 				Str("permission_id", permissionID.String()).
 				Bool("direct_only", directOnly).
 				Msg("Erro ao verificar permissão")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
 	// Responder com o resultado da verificação
-	h.respondWithJSON(w, http.StatusOK, map[string]bool{
-		"hasPermission": hasPermission,
-	})
+	h.respondWithJSON(w, http.StatusOK, PermissionCheckResponse{HasPermission: hasPermission})
 }
\ No newline at end of file
@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// RecertificationCampaignRequest representa a abertura de uma campanha de recertificação
+// Sem user_ids, a campanha abrange todos os titulares das funções; sem escalate_at, o escalamento
+// é calculado a partir do prazo
+type RecertificationCampaignRequest struct {
+	Name                 string      `json:"name"`
+	Description          string      `json:"description,omitempty"`
+	RoleIDs              []uuid.UUID `json:"role_ids"`
+	UserIDs              []uuid.UUID `json:"user_ids,omitempty"`
+	DefaultReviewerID    uuid.UUID   `json:"default_reviewer_id"`
+	EscalationReviewerID uuid.UUID   `json:"escalation_reviewer_id"`
+	Deadline             time.Time   `json:"deadline"`
+	EscalateAt           *time.Time  `json:"escalate_at,omitempty"`
+}
+
+// RecertificationCancelRequest indica o motivo do cancelamento de uma campanha
+type RecertificationCancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RecertificationDecisionRequest representa a decisão do revisor sobre um item
+// A revogação exige um comentário
+type RecertificationDecisionRequest struct {
+	Decision model.RecertificationDecision `json:"decision"`
+	Comment  string                        `json:"comment,omitempty"`
+}
+
+// SetRecertificationService configura o serviço das campanhas de recertificação usado pelo handler
+func (h *RoleHandler) SetRecertificationService(recertificationService application.RecertificationService) {
+	h.recertificationService = recertificationService
+}
+
+// ListRecertificationCampaigns lista as campanhas de recertificação do tenant; status filtra por estado
+func (h *RoleHandler) ListRecertificationCampaigns(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRecertificationCampaigns")
+	defer span.End()
+
+	if !h.recertificationEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	status := model.RecertificationCampaignStatus(r.URL.Query().Get("status"))
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(status)),
+	)
+
+	campaigns, err := h.recertificationService.ListCampaigns(ctx, tenantID, status)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaigns)
+}
+
+// CreateRecertificationCampaign abre uma campanha de recertificação e notifica os revisores
+func (h *RoleHandler) CreateRecertificationCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateRecertificationCampaign")
+	defer span.End()
+
+	if !h.recertificationEnabled(w, r) {
+		return
+	}
+
+	var req RecertificationCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.Int("recertification.roles", len(req.RoleIDs)),
+		attribute.Int("recertification.users", len(req.UserIDs)),
+	)
+
+	campaign, err := h.recertificationService.CreateCampaign(ctx, &application.CreateRecertificationCampaignRequest{
+		TenantID:             tenantID,
+		Name:                 req.Name,
+		Description:          req.Description,
+		RoleIDs:              req.RoleIDs,
+		UserIDs:              req.UserIDs,
+		DefaultReviewerID:    req.DefaultReviewerID,
+		EscalationReviewerID: req.EscalationReviewerID,
+		Deadline:             req.Deadline,
+		EscalateAt:           req.EscalateAt,
+		ActorID:              actorID,
+	})
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, campaign)
+}
+
+// GetRecertificationCampaign obtém uma campanha de recertificação
+func (h *RoleHandler) GetRecertificationCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRecertificationCampaign")
+	defer span.End()
+
+	tenantID, campaignID, ok := h.recertificationCampaignRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.recertificationService.GetCampaign(ctx, tenantID, campaignID)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaign)
+}
+
+// CancelRecertificationCampaign cancela uma campanha ativa
+func (h *RoleHandler) CancelRecertificationCampaign(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CancelRecertificationCampaign")
+	defer span.End()
+
+	tenantID, campaignID, ok := h.recertificationCampaignRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req RecertificationCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	campaign, err := h.recertificationService.CancelCampaign(ctx, tenantID, campaignID, actorID, req.Reason)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaign)
+}
+
+// ListRecertificationItems lista os itens de uma campanha; status e reviewer_id filtram os itens
+func (h *RoleHandler) ListRecertificationItems(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRecertificationItems")
+	defer span.End()
+
+	tenantID, campaignID, ok := h.recertificationCampaignRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	filter := model.RecertificationItemFilter{
+		Status: model.RecertificationItemStatus(r.URL.Query().Get("status")),
+	}
+	if reviewerStr := r.URL.Query().Get("reviewer_id"); reviewerStr != "" {
+		reviewerID, err := uuid.Parse(reviewerStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+			return
+		}
+		filter.ReviewerID = &reviewerID
+	}
+	span.SetAttributes(attribute.String("filter.status", string(filter.Status)))
+
+	items, err := h.recertificationService.ListItems(ctx, tenantID, campaignID, filter)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, items)
+}
+
+// GetRecertificationAttestation obtém o relatório de atestação de uma campanha concluída
+func (h *RoleHandler) GetRecertificationAttestation(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRecertificationAttestation")
+	defer span.End()
+
+	tenantID, campaignID, ok := h.recertificationCampaignRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	attestation, err := h.recertificationService.GetAttestation(ctx, tenantID, campaignID)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, attestation)
+}
+
+// ListRecertificationReviews lista os itens por decidir atribuídos ao usuário autenticado
+func (h *RoleHandler) ListRecertificationReviews(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRecertificationReviews")
+	defer span.End()
+
+	if !h.recertificationEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	reviewerID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("reviewer.id", reviewerID.String()),
+	)
+
+	items, err := h.recertificationService.ListPendingReviews(ctx, tenantID, reviewerID)
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, items)
+}
+
+// DecideRecertificationItem regista a decisão do usuário autenticado sobre um item que lhe foi atribuído
+func (h *RoleHandler) DecideRecertificationItem(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DecideRecertificationItem")
+	defer span.End()
+
+	if !h.recertificationEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	itemID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRecertificationItemID, nil)
+		return
+	}
+
+	var req RecertificationDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	reviewerID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_item.id", itemID.String()),
+		attribute.String("reviewer.id", reviewerID.String()),
+		attribute.String("recertification.decision", string(req.Decision)),
+	)
+
+	item, err := h.recertificationService.Decide(ctx, &application.RecertificationDecisionRequest{
+		TenantID:   tenantID,
+		ItemID:     itemID,
+		ReviewerID: reviewerID,
+		Decision:   req.Decision,
+		Comment:    req.Comment,
+	})
+	if err != nil {
+		h.respondWithRecertificationError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, item)
+}
+
+// recertificationEnabled responde 501 quando as campanhas de recertificação não estão configuradas
+func (h *RoleHandler) recertificationEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.recertificationService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// recertificationCampaignRequest valida a disponibilidade do serviço e extrai o tenant e a campanha
+func (h *RoleHandler) recertificationCampaignRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.recertificationEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	campaignID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRecertificationCampaignID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("recertification_campaign.id", campaignID.String()),
+	)
+	return tenantID, campaignID, true
+}
+
+// respondWithRecertificationError mapeia os erros das campanhas de recertificação para códigos HTTP apropriados
+func (h *RoleHandler) respondWithRecertificationError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar campanha de recertificação")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrRecertificationCampaignNotFound), errors.Is(err, application.ErrRecertificationItemNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidRecertificationCampaign),
+		errors.Is(err, application.ErrInvalidRecertificationDecision),
+		errors.Is(err, application.ErrRecertificationEmptyScope),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrRecertificationNotReviewer), errors.Is(err, application.ErrRecertificationSelfReview):
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+	case errors.Is(err, application.ErrRecertificationItemDecided), errors.Is(err, application.ErrRecertificationCampaignState):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeOperationNotAllowed, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar campanha de recertificação")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// RoleMetadataPolicyResponse representa uma chave protegida dos metadados das funções
+// e o acesso do usuário autenticado a essa chave
+type RoleMetadataPolicyResponse struct {
+	Key             string `json:"key"`
+	ReadPermission  string `json:"readPermission,omitempty"`
+	WritePermission string `json:"writePermission,omitempty"`
+	CanRead         bool   `json:"canRead"`
+	CanWrite        bool   `json:"canWrite"`
+}
+
+// SetRoleMetadataPolicyService configura o serviço de políticas dos metadados das funções
+// Sem serviço configurado os metadados das funções não têm chaves protegidas
+func (h *RoleHandler) SetRoleMetadataPolicyService(roleMetadataPolicyService application.RoleMetadataPolicyService) {
+	h.roleMetadataPolicyService = roleMetadataPolicyService
+}
+
+// ListRoleMetadataPolicies lista as chaves protegidas dos metadados das funções do tenant
+// As políticas são definidas no catálogo de permissões pelas permissões "roles:metadata.<chave>:read|write"
+func (h *RoleHandler) ListRoleMetadataPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRoleMetadataPolicies")
+	defer span.End()
+
+	if h.roleMetadataPolicyService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	policies, err := h.roleMetadataPolicyService.ListPolicies(ctx, tenantID)
+	if err != nil {
+		h.respondWithRoleMetadataPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
+	responseList := make([]RoleMetadataPolicyResponse, len(policies))
+	for i, policy := range policies {
+		responseList[i] = RoleMetadataPolicyResponse{
+			Key:             policy.Key,
+			ReadPermission:  policy.ReadPermission,
+			WritePermission: policy.WritePermission,
+			CanRead:         access.CanRead(policy.Key),
+			CanWrite:        access.CanWrite(policy.Key),
+		}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, responseList)
+}
+
+// roleMetadataAccess calcula as chaves protegidas dos metadados que o usuário autenticado pode ler e alterar
+// Retorna nil, sem restrições, quando as políticas não estão configuradas; em caso de erro responde 500
+func (h *RoleHandler) roleMetadataAccess(w http.ResponseWriter, r *http.Request, span trace.Span) (*model.RoleMetadataAccess, bool) {
+	if h.roleMetadataPolicyService == nil {
+		return nil, true
+	}
+
+	tenantID := h.getTenantID(r)
+	access, err := h.roleMetadataPolicyService.Access(r.Context(), tenantID, h.getUserID(r))
+	if err != nil {
+		h.respondWithRoleMetadataPolicyError(w, r, span, tenantID, err)
+		return nil, false
+	}
+	return access, true
+}
+
+// applyRoleMetadataWrite combina os metadados pedidos com os atuais segundo as políticas
+// Responde 403 com as chaves recusadas quando o pedido altera chaves que o usuário não pode alterar
+func (h *RoleHandler) applyRoleMetadataWrite(w http.ResponseWriter, r *http.Request, span trace.Span, access *model.RoleMetadataAccess, current, requested map[string]interface{}) (map[string]interface{}, bool) {
+	metadata, denied := access.ApplyWrite(current, requested)
+	if len(denied) > 0 {
+		span.SetAttributes(attribute.StringSlice("role_metadata.denied_keys", denied))
+		err := fmt.Errorf("%w: %s", application.ErrRoleMetadataWriteDenied, strings.Join(denied, ", "))
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+		return nil, false
+	}
+	return metadata, true
+}
+
+// respondWithRoleMetadataPolicyError mapeia os erros das políticas dos metadados para códigos HTTP apropriados
+func (h *RoleHandler) respondWithRoleMetadataPolicyError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao verificar políticas dos metadados das funções")
+	span.RecordError(err)
+
+	h.logger.Error().Err(err).
+		Str("tenant_id", tenantID.String()).
+		Msg("Erro ao verificar políticas dos metadados das funções")
+	h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// AcceptRoleSuggestionRequest representa a aceitação de uma sugestão de função
+// Com assign_users, a nova função é atribuída aos usuários que detêm as permissões sugeridas
+type AcceptRoleSuggestionRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	AssignUsers bool   `json:"assign_users,omitempty"`
+}
+
+// SetRoleMiningService configura o serviço de mineração de funções usado pelo handler
+func (h *RoleHandler) SetRoleMiningService(miningService application.RoleMiningService) {
+	h.miningService = miningService
+}
+
+// ListRoleSuggestions lista as sugestões de função do tenant, filtradas por estado
+func (h *RoleHandler) ListRoleSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRoleSuggestions")
+	defer span.End()
+
+	if !h.roleSuggestionsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	status := r.URL.Query().Get("status")
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", status),
+	)
+
+	suggestions, err := h.miningService.ListSuggestions(ctx, tenantID, status)
+	if err != nil {
+		h.respondWithRoleSuggestionError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, suggestions)
+}
+
+// MineRoleSuggestions recalcula de imediato as sugestões pendentes do tenant
+func (h *RoleHandler) MineRoleSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.MineRoleSuggestions")
+	defer span.End()
+
+	if !h.roleSuggestionsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	suggestions, err := h.miningService.MineTenant(ctx, tenantID)
+	if err != nil {
+		h.respondWithRoleSuggestionError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, suggestions)
+}
+
+// GetRoleSuggestion obtém uma sugestão de função com as permissões e os usuários abrangidos
+func (h *RoleHandler) GetRoleSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRoleSuggestion")
+	defer span.End()
+
+	tenantID, suggestionID, ok := h.roleSuggestionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.miningService.GetSuggestion(ctx, tenantID, suggestionID)
+	if err != nil {
+		h.respondWithRoleSuggestionError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, suggestion)
+}
+
+// AcceptRoleSuggestion cria a função sugerida e marca a sugestão como aceite
+func (h *RoleHandler) AcceptRoleSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.AcceptRoleSuggestion")
+	defer span.End()
+
+	tenantID, suggestionID, ok := h.roleSuggestionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req AcceptRoleSuggestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.Code == "" {
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleCodeRequired, nil)
+		return
+	}
+	if req.Name == "" {
+		h.respondWithErrorMessage(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, i18n.MessageRoleNameRequired, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("actor.id", actorID.String()),
+		attribute.Bool("assign_users", req.AssignUsers),
+	)
+
+	acceptance, err := h.miningService.AcceptSuggestion(ctx, &application.AcceptRoleSuggestionRequest{
+		TenantID:     tenantID,
+		SuggestionID: suggestionID,
+		RoleCode:     req.Code,
+		RoleName:     req.Name,
+		Description:  req.Description,
+		AssignUsers:  req.AssignUsers,
+		AcceptedBy:   actorID,
+	})
+	if err != nil {
+		h.respondWithRoleSuggestionError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, acceptance)
+}
+
+// DismissRoleSuggestion descarta a sugestão; o mesmo conjunto de permissões não volta a ser sugerido
+func (h *RoleHandler) DismissRoleSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DismissRoleSuggestion")
+	defer span.End()
+
+	tenantID, suggestionID, ok := h.roleSuggestionRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	suggestion, err := h.miningService.DismissSuggestion(ctx, tenantID, suggestionID, actorID)
+	if err != nil {
+		h.respondWithRoleSuggestionError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, suggestion)
+}
+
+// roleSuggestionsEnabled responde 501 quando a mineração de funções não está configurada
+func (h *RoleHandler) roleSuggestionsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.miningService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// roleSuggestionRequest valida a disponibilidade do serviço e extrai o tenant e a sugestão
+func (h *RoleHandler) roleSuggestionRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.roleSuggestionsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	suggestionID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidSuggestionID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("role_suggestion.id", suggestionID.String()),
+	)
+	return tenantID, suggestionID, true
+}
+
+// respondWithRoleSuggestionError mapeia os erros da mineração de funções para códigos HTTP apropriados
+func (h *RoleHandler) respondWithRoleSuggestionError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar sugestão de função")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrRoleSuggestionNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidRoleSuggestion):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrRoleSuggestionNotPending), errors.Is(err, application.ErrRoleCodeAlreadyExists):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar sugestão de função")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
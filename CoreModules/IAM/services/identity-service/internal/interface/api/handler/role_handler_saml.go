@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// SAMLProviderRequest representa o registo de um fornecedor de identidade SAML no tenant autenticado
+// Quando metadataXml é informado, entityId, ssoUrl, signingCertificates e nameIdFormat são lidos dele
+type SAMLProviderRequest struct {
+	Name                string                      `json:"name"`
+	MetadataXML         string                      `json:"metadataXml,omitempty"`
+	EntityID            string                      `json:"entityId,omitempty"`
+	SSOURL              string                      `json:"ssoUrl,omitempty"`
+	SigningCertificates []string                    `json:"signingCertificates,omitempty"`
+	NameIDFormat        string                      `json:"nameIdFormat,omitempty"`
+	AttributeMapping    model.SAMLAttributeMapping  `json:"attributeMapping"`
+	RoleMappingRules    []model.SAMLRoleMappingRule `json:"roleMappingRules,omitempty"`
+	JITProvisioning     bool                        `json:"jitProvisioning"`
+}
+
+// SAMLProviderUpdateRequest representa a alteração de um fornecedor de identidade
+// Os campos omitidos mantêm o valor atual; metadataXml substitui os dados do IdP
+type SAMLProviderUpdateRequest struct {
+	Name             string                      `json:"name,omitempty"`
+	MetadataXML      string                      `json:"metadataXml,omitempty"`
+	AttributeMapping *model.SAMLAttributeMapping `json:"attributeMapping,omitempty"`
+	RoleMappingRules []model.SAMLRoleMappingRule `json:"roleMappingRules,omitempty"`
+	JITProvisioning  *bool                       `json:"jitProvisioning,omitempty"`
+	IsActive         *bool                       `json:"isActive,omitempty"`
+}
+
+// SAMLAssertionForm representa o formulário publicado pelo IdP no binding HTTP-POST
+type SAMLAssertionForm struct {
+	SAMLResponse string `json:"SAMLResponse"`
+	RelayState   string `json:"RelayState"`
+}
+
+// SetSAMLFederationService configura o serviço de federação SAML usado pelo handler
+func (h *RoleHandler) SetSAMLFederationService(samlService application.SAMLFederationService) {
+	h.samlService = samlService
+}
+
+// RegisterSAMLProvider regista um fornecedor de identidade SAML no tenant
+func (h *RoleHandler) RegisterSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RegisterSAMLProvider")
+	defer span.End()
+
+	if !h.samlFederationEnabled(w, r) {
+		return
+	}
+
+	var req SAMLProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	provider, err := h.samlService.RegisterProvider(ctx, &application.RegisterSAMLProviderRequest{
+		TenantID:            tenantID,
+		Name:                req.Name,
+		MetadataXML:         req.MetadataXML,
+		EntityID:            req.EntityID,
+		SSOURL:              req.SSOURL,
+		SigningCertificates: req.SigningCertificates,
+		NameIDFormat:        req.NameIDFormat,
+		AttributeMapping:    req.AttributeMapping,
+		RoleMappingRules:    req.RoleMappingRules,
+		JITProvisioning:     req.JITProvisioning,
+		CreatedBy:           h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, provider)
+}
+
+// ListSAMLProviders lista os fornecedores de identidade SAML do tenant
+func (h *RoleHandler) ListSAMLProviders(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListSAMLProviders")
+	defer span.End()
+
+	if !h.samlFederationEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	providers, err := h.samlService.ListProviders(ctx, tenantID)
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, tenantID, err)
+		return
+	}
+	if providers == nil {
+		providers = []*model.SAMLIdentityProvider{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, providers)
+}
+
+// GetSAMLProvider obtém um fornecedor de identidade SAML do tenant
+func (h *RoleHandler) GetSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetSAMLProvider")
+	defer span.End()
+
+	providerID, ok := h.samlProviderRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	provider, err := h.samlService.GetProvider(ctx, tenantID, providerID)
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, provider)
+}
+
+// UpdateSAMLProvider altera um fornecedor de identidade SAML do tenant
+func (h *RoleHandler) UpdateSAMLProvider(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateSAMLProvider")
+	defer span.End()
+
+	providerID, ok := h.samlProviderRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req SAMLProviderUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	provider, err := h.samlService.UpdateProvider(ctx, &application.UpdateSAMLProviderRequest{
+		TenantID:         tenantID,
+		ProviderID:       providerID,
+		Name:             req.Name,
+		MetadataXML:      req.MetadataXML,
+		AttributeMapping: req.AttributeMapping,
+		RoleMappingRules: req.RoleMappingRules,
+		JITProvisioning:  req.JITProvisioning,
+		IsActive:         req.IsActive,
+		UpdatedBy:        h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, provider)
+}
+
+// GetSAMLServiceProviderMetadata devolve os metadados do SP a registar no IdP
+func (h *RoleHandler) GetSAMLServiceProviderMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetSAMLServiceProviderMetadata")
+	defer span.End()
+
+	providerID, ok := h.samlProviderRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	metadata, err := h.samlService.ServiceProviderMetadata(ctx, providerID)
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(metadata)
+}
+
+// StartSAMLLogin redireciona o navegador para o IdP com um pedido de autenticação
+// O parâmetro returnTo, opcional, indica o caminho relativo a apresentar após o login
+func (h *RoleHandler) StartSAMLLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.StartSAMLLogin")
+	defer span.End()
+
+	providerID, ok := h.samlProviderRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	redirect, err := h.samlService.StartLogin(ctx, providerID, r.URL.Query().Get("returnTo"))
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	http.Redirect(w, r, redirect.RedirectURL, http.StatusFound)
+}
+
+// ConsumeSAMLAssertion recebe a resposta do IdP (binding HTTP-POST) e conclui o login federado
+func (h *RoleHandler) ConsumeSAMLAssertion(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ConsumeSAMLAssertion")
+	defer span.End()
+
+	providerID, ok := h.samlProviderRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	form := SAMLAssertionForm{
+		SAMLResponse: r.PostForm.Get("SAMLResponse"),
+		RelayState:   r.PostForm.Get("RelayState"),
+	}
+	if form.SAMLResponse == "" || form.RelayState == "" {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	result, err := h.samlService.CompleteLogin(ctx, providerID, form.SAMLResponse, form.RelayState)
+	if err != nil {
+		h.respondWithSAMLError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	if result.LinkCandidate != nil {
+		// A conta local com o mesmo email tem de ser ligada pelo usuário antes do login federado
+		span.SetAttributes(attribute.String("account_link_candidate.id", result.LinkCandidate.ID.String()))
+		h.respondWithJSON(w, http.StatusConflict, result)
+		return
+	}
+	if len(result.PendingDocuments) > 0 {
+		// Os documentos legais obrigatórios têm de ser aceites antes de o login ser concluído
+		span.SetAttributes(
+			attribute.String("user.id", result.User.ID.String()),
+			attribute.Int("legal_document.pending", len(result.PendingDocuments)),
+		)
+		h.respondWithJSON(w, http.StatusForbidden, result)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user.id", result.Identity.UserID.String()),
+		attribute.Bool("saml.provisioned", result.Provisioned),
+	)
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+// samlFederationEnabled responde 501 quando a federação SAML não está configurada
+func (h *RoleHandler) samlFederationEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.samlService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// samlProviderRequest valida a disponibilidade do serviço e extrai o fornecedor de identidade
+func (h *RoleHandler) samlProviderRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, bool) {
+	if !h.samlFederationEnabled(w, r) {
+		return uuid.Nil, false
+	}
+
+	providerID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidSAMLProviderID, nil)
+		return uuid.Nil, false
+	}
+
+	span.SetAttributes(attribute.String("saml_provider.id", providerID.String()))
+	return providerID, true
+}
+
+// respondWithSAMLError mapeia os erros da federação SAML para códigos HTTP apropriados
+// Os motivos da rejeição de uma resposta do IdP não são devolvidos ao cliente
+func (h *RoleHandler) respondWithSAMLError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar federação SAML")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrSAMLProviderNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidSAMLProvider),
+		errors.Is(err, application.ErrInvalidSAMLMetadata),
+		errors.Is(err, application.ErrInvalidSAMLReturnTo),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrSAMLProviderAlreadyExists),
+		errors.Is(err, application.ErrSAMLAccountConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrSAMLProviderInactive):
+		h.respondWithError(w, r, http.StatusUnprocessableEntity, i18n.CodeOperationNotAllowed, err)
+	case errors.Is(err, application.ErrInvalidSAMLResponse),
+		errors.Is(err, application.ErrSAMLRequestNotFound):
+		h.respondWithError(w, r, http.StatusUnauthorized, i18n.CodeAuthenticationFailed, nil)
+	case errors.Is(err, application.ErrSAMLProvisioningDisabled),
+		errors.Is(err, application.ErrFederatedUserNotAllowed):
+		h.respondWithError(w, r, http.StatusForbidden, i18n.CodeForbidden, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar federação SAML")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// Número máximo de incidentes devolvidos por listagem
+const maxSecurityIncidentListLimit = 100
+
+// SecurityIncidentTriageRequest representa a nota do analista que faz a triagem de um incidente
+// A nota é obrigatória para resolver ou descartar o incidente
+type SecurityIncidentTriageRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// SetAuditAnomalyService configura o serviço de deteção de anomalias usado pelo handler
+func (h *RoleHandler) SetAuditAnomalyService(anomalyService application.AuditAnomalyService) {
+	h.anomalyService = anomalyService
+}
+
+// ListSecurityIncidents lista os incidentes de segurança do tenant, filtrados por estado,
+// severidade, regra e data de deteção
+func (h *RoleHandler) ListSecurityIncidents(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListSecurityIncidents")
+	defer span.End()
+
+	if !h.securityIncidentsEnabled(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.SecurityIncidentFilter{
+		Status:   model.SecurityIncidentStatus(query.Get("status")),
+		Severity: model.SecurityIncidentSeverity(query.Get("severity")),
+		Rule:     model.SecurityIncidentRule(query.Get("rule")),
+		Limit:    maxSecurityIncidentListLimit,
+	}
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+			return
+		}
+		filter.Since = &since
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit <= maxSecurityIncidentListLimit {
+			filter.Limit = limit
+		}
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(filter.Status)),
+		attribute.String("filter.severity", string(filter.Severity)),
+	)
+
+	incidents, err := h.anomalyService.ListIncidents(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithSecurityIncidentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, incidents)
+}
+
+// GetSecurityIncident obtém um incidente de segurança com as evidências recolhidas
+func (h *RoleHandler) GetSecurityIncident(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetSecurityIncident")
+	defer span.End()
+
+	tenantID, incidentID, ok := h.securityIncidentRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	incident, err := h.anomalyService.GetIncident(ctx, tenantID, incidentID)
+	if err != nil {
+		h.respondWithSecurityIncidentError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, incident)
+}
+
+// AcknowledgeSecurityIncident assinala que o incidente está a ser analisado
+func (h *RoleHandler) AcknowledgeSecurityIncident(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.AcknowledgeSecurityIncident")
+	defer span.End()
+
+	triage, ok := h.securityIncidentTriage(w, r, span)
+	if !ok {
+		return
+	}
+
+	incident, err := h.anomalyService.Acknowledge(ctx, triage)
+	if err != nil {
+		h.respondWithSecurityIncidentError(w, r, span, triage.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, incident)
+}
+
+// ResolveSecurityIncident fecha o incidente após a correção
+func (h *RoleHandler) ResolveSecurityIncident(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ResolveSecurityIncident")
+	defer span.End()
+
+	triage, ok := h.securityIncidentTriage(w, r, span)
+	if !ok {
+		return
+	}
+
+	incident, err := h.anomalyService.Resolve(ctx, triage)
+	if err != nil {
+		h.respondWithSecurityIncidentError(w, r, span, triage.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, incident)
+}
+
+// DismissSecurityIncident fecha o incidente como falso positivo
+func (h *RoleHandler) DismissSecurityIncident(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DismissSecurityIncident")
+	defer span.End()
+
+	triage, ok := h.securityIncidentTriage(w, r, span)
+	if !ok {
+		return
+	}
+
+	incident, err := h.anomalyService.Dismiss(ctx, triage)
+	if err != nil {
+		h.respondWithSecurityIncidentError(w, r, span, triage.TenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, incident)
+}
+
+// securityIncidentsEnabled responde 501 quando a deteção de anomalias não está configurada
+func (h *RoleHandler) securityIncidentsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.anomalyService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// securityIncidentRequest valida a disponibilidade do serviço e extrai o tenant e o incidente
+func (h *RoleHandler) securityIncidentRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.securityIncidentsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	incidentID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidIncidentID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("security_incident.id", incidentID.String()),
+	)
+	return tenantID, incidentID, true
+}
+
+// securityIncidentTriage extrai a triagem do analista autenticado sobre o incidente
+// O corpo é opcional no reconhecimento
+func (h *RoleHandler) securityIncidentTriage(w http.ResponseWriter, r *http.Request, span trace.Span) (*application.SecurityIncidentTriage, bool) {
+	tenantID, incidentID, ok := h.securityIncidentRequest(w, r, span)
+	if !ok {
+		return nil, false
+	}
+
+	var req SecurityIncidentTriageRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return nil, false
+		}
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	return &application.SecurityIncidentTriage{
+		TenantID:   tenantID,
+		IncidentID: incidentID,
+		ActorID:    actorID,
+		Note:       req.Note,
+	}, true
+}
+
+// respondWithSecurityIncidentError mapeia os erros dos incidentes de segurança para códigos HTTP apropriados
+func (h *RoleHandler) respondWithSecurityIncidentError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar incidente de segurança")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrSecurityIncidentNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidSecurityIncident):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrInvalidSecurityIncidentTransition):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrSecurityIncidentConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConcurrentModification, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar incidente de segurança")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
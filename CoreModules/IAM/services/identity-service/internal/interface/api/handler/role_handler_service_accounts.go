@@ -0,0 +1,532 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// ServiceAccountRequest representa a criação ou a alteração de uma conta de serviço
+type ServiceAccountRequest struct {
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	Status      model.ServiceAccountStatus `json:"status,omitempty"`
+}
+
+// ServiceAccountCredentialRequest representa a emissão de uma credencial de uma conta de serviço
+// O certificado em PEM só é usado nas credenciais mtls_cert
+type ServiceAccountCredentialRequest struct {
+	Type           model.ServiceAccountCredentialType `json:"type"`
+	Name           string                             `json:"name,omitempty"`
+	ExpiresAt      *time.Time                         `json:"expires_at,omitempty"`
+	CertificatePEM string                             `json:"certificate_pem,omitempty"`
+}
+
+// ServiceAccountTokenRequest representa a autenticação de uma conta de serviço
+// O pedido é enviado em JSON ou como application/x-www-form-urlencoded, com os mesmos nomes; as
+// credenciais do cliente OIDC podem também ser enviadas por HTTP Basic. Sem credenciais no pedido,
+// é usado o certificado de cliente apresentado na ligação TLS
+type ServiceAccountTokenRequest struct {
+	APIKey            string `json:"api_key,omitempty"`
+	ClientID          string `json:"client_id,omitempty"`
+	ClientSecret      string `json:"client_secret,omitempty"`
+	KubernetesCluster string `json:"kubernetes_cluster,omitempty"`
+	KubernetesToken   string `json:"kubernetes_token,omitempty"`
+}
+
+// SetServiceAccountService configura o serviço das contas de serviço usado pelo handler
+func (h *RoleHandler) SetServiceAccountService(serviceAccountService application.ServiceAccountService) {
+	h.serviceAccountService = serviceAccountService
+}
+
+// ListServiceAccounts lista as contas de serviço do tenant, opcionalmente filtradas pelo estado
+func (h *RoleHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListServiceAccounts")
+	defer span.End()
+
+	if !h.serviceAccountsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	filter := model.ServiceAccountFilter{Status: model.ServiceAccountStatus(r.URL.Query().Get("status"))}
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.status", string(filter.Status)),
+	)
+
+	accounts, err := h.serviceAccountService.ListServiceAccounts(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, accounts)
+}
+
+// CreateServiceAccount cria uma conta de serviço no tenant
+func (h *RoleHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateServiceAccount")
+	defer span.End()
+
+	if !h.serviceAccountsEnabled(w, r) {
+		return
+	}
+
+	var req ServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("service_account.name", req.Name),
+	)
+
+	account, err := h.serviceAccountService.CreateServiceAccount(ctx, &application.SaveServiceAccountRequest{
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		ActorID:     actorID,
+	})
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, account)
+}
+
+// GetServiceAccount obtém uma conta de serviço do tenant
+func (h *RoleHandler) GetServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetServiceAccount")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	account, err := h.serviceAccountService.GetServiceAccount(ctx, tenantID, accountID)
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// UpdateServiceAccount altera o nome, a descrição e o estado de uma conta de serviço
+func (h *RoleHandler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateServiceAccount")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req ServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	account, err := h.serviceAccountService.UpdateServiceAccount(ctx, &application.SaveServiceAccountRequest{
+		TenantID:    tenantID,
+		AccountID:   accountID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      req.Status,
+		ActorID:     actorID,
+	})
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// BindServiceAccountRole atribui uma função do tenant à conta de serviço
+func (h *RoleHandler) BindServiceAccountRole(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.BindServiceAccountRole")
+	defer span.End()
+
+	tenantID, accountID, roleID, ok := h.serviceAccountRoleRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	account, err := h.serviceAccountService.BindRole(ctx, tenantID, accountID, roleID, h.getUserID(r))
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// UnbindServiceAccountRole retira uma função da conta de serviço
+func (h *RoleHandler) UnbindServiceAccountRole(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UnbindServiceAccountRole")
+	defer span.End()
+
+	tenantID, accountID, roleID, ok := h.serviceAccountRoleRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	account, err := h.serviceAccountService.UnbindRole(ctx, tenantID, accountID, roleID, h.getUserID(r))
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// BindWorkloadIdentity liga a conta de serviço a uma conta de serviço Kubernetes
+func (h *RoleHandler) BindWorkloadIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.BindWorkloadIdentity")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var binding model.WorkloadIdentityBinding
+	if err := json.NewDecoder(r.Body).Decode(&binding); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	span.SetAttributes(attribute.String("kubernetes.cluster", binding.Cluster))
+
+	account, err := h.serviceAccountService.BindWorkloadIdentity(ctx, tenantID, accountID, binding, h.getUserID(r))
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// UnbindWorkloadIdentity remove a ligação da conta de serviço ao Kubernetes
+func (h *RoleHandler) UnbindWorkloadIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UnbindWorkloadIdentity")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	account, err := h.serviceAccountService.UnbindWorkloadIdentity(ctx, tenantID, accountID, h.getUserID(r))
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, account)
+}
+
+// ListServiceAccountCredentials lista as credenciais da conta de serviço, sem os segredos
+func (h *RoleHandler) ListServiceAccountCredentials(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListServiceAccountCredentials")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.serviceAccountService.ListCredentials(ctx, tenantID, accountID)
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, credentials)
+}
+
+// IssueServiceAccountCredential emite uma credencial da conta de serviço
+// O segredo gerado só é devolvido nesta resposta
+func (h *RoleHandler) IssueServiceAccountCredential(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.IssueServiceAccountCredential")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req ServiceAccountCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("credential.type", string(req.Type)),
+	)
+
+	issued, err := h.serviceAccountService.IssueCredential(ctx, &application.IssueServiceAccountCredentialRequest{
+		TenantID:       tenantID,
+		AccountID:      accountID,
+		Type:           req.Type,
+		Name:           req.Name,
+		ExpiresAt:      req.ExpiresAt,
+		CertificatePEM: req.CertificatePEM,
+		ActorID:        actorID,
+	})
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.respondWithJSON(w, http.StatusCreated, issued)
+}
+
+// RevokeServiceAccountCredential revoga uma credencial da conta de serviço
+func (h *RoleHandler) RevokeServiceAccountCredential(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.RevokeServiceAccountCredential")
+	defer span.End()
+
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return
+	}
+	credentialID, err := uuid.Parse(mux.Vars(r)["credentialId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidServiceAccountCredentialID, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("credential.id", credentialID.String()),
+	)
+
+	if err := h.serviceAccountService.RevokeCredential(ctx, tenantID, accountID, credentialID, actorID); err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServiceAccountUsage resume a utilização das contas de serviço do tenant e identifica as inativas
+// Parâmetros opcionais: days (período, 30 por padrão) e dormant=true (apenas as contas inativas)
+func (h *RoleHandler) GetServiceAccountUsage(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetServiceAccountUsage")
+	defer span.End()
+
+	if !h.serviceAccountsEnabled(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := application.ServiceAccountUsageFilter{DormantOnly: query.Get("dormant") == "true"}
+	if value := query.Get("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		filter.Days = days
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	report, err := h.serviceAccountService.UsageReport(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithServiceAccountError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
+
+// IssueServiceAccountToken autentica uma conta de serviço e emite um token de acesso
+// Os erros seguem o formato do RFC 6749: credenciais recusadas respondem 401 invalid_client
+func (h *RoleHandler) IssueServiceAccountToken(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.IssueServiceAccountToken")
+	defer span.End()
+
+	if !h.serviceAccountsEnabled(w, r) {
+		return
+	}
+
+	req, err := decodeServiceAccountTokenRequest(r)
+	if err != nil {
+		h.respondWithOAuthError(w, span, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+	req.IPAddress = h.clientIP(r)
+
+	resp, err := h.serviceAccountService.Authenticate(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrInvalidServiceAccountAuthentication):
+			h.respondWithOAuthError(w, span, http.StatusBadRequest, "invalid_request", err)
+		case errors.Is(err, application.ErrServiceAccountAuthenticationFailed):
+			h.respondWithOAuthError(w, span, http.StatusUnauthorized, "invalid_client", nil)
+		default:
+			h.logger.Error().Err(err).Msg("Erro ao autenticar conta de serviço")
+			h.respondWithOAuthError(w, span, http.StatusInternalServerError, "server_error", nil)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+// decodeServiceAccountTokenRequest lê o pedido de autenticação em formulário ou em JSON
+// O certificado de cliente só é considerado quando o pedido não traz outras credenciais
+func decodeServiceAccountTokenRequest(r *http.Request) (*application.ServiceAccountAuthRequest, error) {
+	var body ServiceAccountTokenRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errors.New("corpo do pedido inválido")
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, errors.New("formulário do pedido inválido")
+		}
+		body = ServiceAccountTokenRequest{
+			APIKey:            r.PostForm.Get("api_key"),
+			ClientID:          r.PostForm.Get("client_id"),
+			ClientSecret:      r.PostForm.Get("client_secret"),
+			KubernetesCluster: r.PostForm.Get("kubernetes_cluster"),
+			KubernetesToken:   r.PostForm.Get("kubernetes_token"),
+		}
+	}
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		if body.ClientID != "" || body.ClientSecret != "" {
+			return nil, errors.New("credenciais do cliente enviadas no corpo e em HTTP Basic")
+		}
+		body.ClientID, body.ClientSecret = clientID, clientSecret
+	}
+
+	req := &application.ServiceAccountAuthRequest{
+		APIKey:            body.APIKey,
+		ClientID:          body.ClientID,
+		ClientSecret:      body.ClientSecret,
+		KubernetesCluster: body.KubernetesCluster,
+		KubernetesToken:   body.KubernetesToken,
+	}
+	noCredentials := body == (ServiceAccountTokenRequest{})
+	if noCredentials && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		req.CertificateFingerprint = model.CertificateFingerprint(r.TLS.PeerCertificates[0].Raw)
+	}
+	return req, nil
+}
+
+// serviceAccountsEnabled responde 501 quando as contas de serviço não estão configuradas
+func (h *RoleHandler) serviceAccountsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.serviceAccountService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// serviceAccountRequest valida a disponibilidade do serviço e extrai o tenant e a conta de serviço
+func (h *RoleHandler) serviceAccountRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.serviceAccountsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	accountID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidServiceAccountID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("service_account.id", accountID.String()),
+	)
+	return tenantID, accountID, true
+}
+
+// serviceAccountRoleRequest extrai o tenant, a conta de serviço e a função do pedido
+func (h *RoleHandler) serviceAccountRoleRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	tenantID, accountID, ok := h.serviceAccountRequest(w, r, span)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	roleID, err := uuid.Parse(mux.Vars(r)["roleId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("role.id", roleID.String()),
+		attribute.String("actor.id", h.getUserID(r).String()),
+	)
+	return tenantID, accountID, roleID, true
+}
+
+// respondWithServiceAccountError mapeia os erros das contas de serviço para códigos HTTP apropriados
+func (h *RoleHandler) respondWithServiceAccountError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar conta de serviço")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrServiceAccountNotFound),
+		errors.Is(err, application.ErrServiceAccountRoleNotFound),
+		errors.Is(err, application.ErrServiceAccountRoleNotBound),
+		errors.Is(err, application.ErrServiceAccountCredentialNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidServiceAccount),
+		errors.Is(err, application.ErrInvalidWorkloadIdentity),
+		errors.Is(err, application.ErrInvalidServiceAccountCredential),
+		errors.Is(err, application.ErrInvalidServiceAccountUsageFilter),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrServiceAccountConflict),
+		errors.Is(err, application.ErrWorkloadIdentityConflict),
+		errors.Is(err, application.ErrServiceAccountCredentialConflict),
+		errors.Is(err, application.ErrServiceAccountRoleLimit),
+		errors.Is(err, application.ErrServiceAccountCredentialLimit):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar conta de serviço")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
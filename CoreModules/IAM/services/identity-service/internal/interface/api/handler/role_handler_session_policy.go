@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// SessionPolicyRequest representa a política de sessão do tenant autenticado
+// Os valores omitidos usam os padrões do mercado; sem mercado no corpo, é usado o cabeçalho X-Market
+type SessionPolicyRequest struct {
+	Market                  string `json:"market,omitempty"`
+	FinancialTenant         bool   `json:"financial_tenant"`
+	AccessTokenTTLSeconds   int    `json:"access_token_ttl_seconds,omitempty"`
+	RefreshTokenTTLSeconds  int    `json:"refresh_token_ttl_seconds,omitempty"`
+	AbsoluteLifetimeSeconds int    `json:"absolute_lifetime_seconds,omitempty"`
+	IdleTimeoutSeconds      int    `json:"idle_timeout_seconds,omitempty"`
+}
+
+// SetSessionPolicyService configura o serviço de políticas de sessão usado pelo handler
+func (h *RoleHandler) SetSessionPolicyService(sessionPolicyService application.SessionPolicyService) {
+	h.sessionPolicyService = sessionPolicyService
+}
+
+// GetSessionPolicy obtém a política de sessão do tenant, ou os valores padrão do mercado
+func (h *RoleHandler) GetSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetSessionPolicy")
+	defer span.End()
+
+	if !h.sessionPoliciesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	policy, err := h.sessionPolicyService.GetPolicy(ctx, tenantID, r.Header.Get("X-Market"))
+	if err != nil {
+		h.respondWithSessionPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// UpdateSessionPolicy altera a política de sessão do tenant
+func (h *RoleHandler) UpdateSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateSessionPolicy")
+	defer span.End()
+
+	if !h.sessionPoliciesEnabled(w, r) {
+		return
+	}
+
+	var req SessionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+	if req.Market == "" {
+		req.Market = r.Header.Get("X-Market")
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("session_policy.market", req.Market),
+		attribute.Bool("session_policy.financial_tenant", req.FinancialTenant),
+	)
+
+	policy, err := h.sessionPolicyService.UpdatePolicy(ctx, &application.UpdateSessionPolicyRequest{
+		TenantID:                tenantID,
+		Market:                  req.Market,
+		FinancialTenant:         req.FinancialTenant,
+		AccessTokenTTLSeconds:   req.AccessTokenTTLSeconds,
+		RefreshTokenTTLSeconds:  req.RefreshTokenTTLSeconds,
+		AbsoluteLifetimeSeconds: req.AbsoluteLifetimeSeconds,
+		IdleTimeoutSeconds:      req.IdleTimeoutSeconds,
+		UpdatedBy:               actorID,
+	})
+	if err != nil {
+		h.respondWithSessionPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// ResetSessionPolicy remove a política de sessão do tenant, que volta aos valores padrão do mercado
+func (h *RoleHandler) ResetSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ResetSessionPolicy")
+	defer span.End()
+
+	if !h.sessionPoliciesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+	)
+
+	if err := h.sessionPolicyService.ResetPolicy(ctx, tenantID, actorID); err != nil {
+		h.respondWithSessionPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSessionLifetimes calcula a validade dos tokens a emitir, usada pelos emissores de tokens
+// auth_time (RFC 3339) indica o momento da autenticação; sem ele a sessão começa agora
+func (h *RoleHandler) GetSessionLifetimes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetSessionLifetimes")
+	defer span.End()
+
+	if !h.sessionPoliciesEnabled(w, r) {
+		return
+	}
+
+	var authTime time.Time
+	if value := r.URL.Query().Get("auth_time"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		authTime = parsed.UTC()
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	lifetimes, err := h.sessionPolicyService.Lifetimes(ctx, tenantID, r.Header.Get("X-Market"), authTime)
+	if err != nil {
+		h.respondWithSessionPolicyError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, lifetimes)
+}
+
+// sessionPoliciesEnabled responde 501 quando as políticas de sessão não estão configuradas
+func (h *RoleHandler) sessionPoliciesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.sessionPolicyService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// respondWithSessionPolicyError mapeia os erros das políticas de sessão para códigos HTTP apropriados
+func (h *RoleHandler) respondWithSessionPolicyError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar política de sessão")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrInvalidSessionPolicy),
+		errors.Is(err, application.ErrSessionPolicyExceedsMarket),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar política de sessão")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
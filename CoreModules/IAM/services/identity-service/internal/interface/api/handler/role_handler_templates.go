@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// RoleTemplateCreateRequest representa a publicação de um modelo no catálogo global
+type RoleTemplateCreateRequest struct {
+	Code            string                 `json:"code"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description,omitempty"`
+	Market          string                 `json:"market,omitempty"`
+	PermissionCodes []string               `json:"permissionCodes"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// RoleTemplateUpdateRequest representa a revisão de um modelo
+// A omissão das permissões mantém as atuais; a sua alteração avança a versão do modelo
+type RoleTemplateUpdateRequest struct {
+	Name            string   `json:"name,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	PermissionCodes []string `json:"permissionCodes,omitempty"`
+	IsActive        *bool    `json:"isActive,omitempty"`
+}
+
+// RoleTemplateInstantiateRequest representa a instanciação de um modelo no tenant autenticado
+type RoleTemplateInstantiateRequest struct {
+	Market            string            `json:"market,omitempty"`
+	RoleCode          string            `json:"roleCode,omitempty"`
+	RoleName          string            `json:"roleName,omitempty"`
+	PermissionMapping map[string]string `json:"permissionMapping,omitempty"`
+	AllowPartial      bool              `json:"allowPartial,omitempty"`
+}
+
+// SetRoleTemplateService configura o serviço de modelos de função usado pelo handler
+func (h *RoleHandler) SetRoleTemplateService(templateService application.RoleTemplateService) {
+	h.templateService = templateService
+}
+
+// CreateRoleTemplate publica um modelo no catálogo global
+func (h *RoleHandler) CreateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateRoleTemplate")
+	defer span.End()
+
+	if !h.roleTemplatesEnabled(w, r) {
+		return
+	}
+
+	var req RoleTemplateCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("template.code", req.Code),
+		attribute.String("template.market", req.Market),
+	)
+
+	template, err := h.templateService.CreateTemplate(ctx, &application.CreateRoleTemplateRequest{
+		Code:            req.Code,
+		Name:            req.Name,
+		Description:     req.Description,
+		Market:          req.Market,
+		PermissionCodes: req.PermissionCodes,
+		Metadata:        req.Metadata,
+		CreatedBy:       h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, template)
+}
+
+// ListRoleTemplates lista o catálogo de modelos, opcionalmente restrito a um mercado
+func (h *RoleHandler) ListRoleTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRoleTemplates")
+	defer span.End()
+
+	if !h.roleTemplatesEnabled(w, r) {
+		return
+	}
+
+	filter := model.RoleTemplateFilter{
+		Market:     r.URL.Query().Get("market"),
+		ActiveOnly: r.URL.Query().Get("includeInactive") != "true",
+	}
+	span.SetAttributes(
+		attribute.String("filter.market", filter.Market),
+		attribute.Bool("filter.active_only", filter.ActiveOnly),
+	)
+
+	templates, err := h.templateService.ListTemplates(ctx, filter)
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, templates)
+}
+
+// GetRoleTemplate obtém um modelo do catálogo
+func (h *RoleHandler) GetRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRoleTemplate")
+	defer span.End()
+
+	templateID, ok := h.roleTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(ctx, templateID)
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// UpdateRoleTemplate revê um modelo do catálogo
+func (h *RoleHandler) UpdateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateRoleTemplate")
+	defer span.End()
+
+	templateID, ok := h.roleTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req RoleTemplateUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(ctx, &application.UpdateRoleTemplateRequest{
+		TemplateID:      templateID,
+		Name:            req.Name,
+		Description:     req.Description,
+		PermissionCodes: req.PermissionCodes,
+		IsActive:        req.IsActive,
+		UpdatedBy:       h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, uuid.Nil, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// InstantiateRoleTemplate cria no tenant autenticado uma função a partir de um modelo
+func (h *RoleHandler) InstantiateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.InstantiateRoleTemplate")
+	defer span.End()
+
+	templateID, ok := h.roleTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req RoleTemplateInstantiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("market", req.Market),
+		attribute.Bool("allow_partial", req.AllowPartial),
+	)
+
+	instantiation, err := h.templateService.Instantiate(ctx, &application.InstantiateRoleTemplateRequest{
+		TemplateID:        templateID,
+		TenantID:          tenantID,
+		Market:            req.Market,
+		RoleCode:          req.RoleCode,
+		RoleName:          req.RoleName,
+		PermissionMapping: req.PermissionMapping,
+		AllowPartial:      req.AllowPartial,
+		InstantiatedBy:    h.getUserID(r),
+	})
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, instantiation)
+}
+
+// ListRoleTemplateInstances lista as funções do tenant instanciadas a partir de modelos
+func (h *RoleHandler) ListRoleTemplateInstances(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListRoleTemplateInstances")
+	defer span.End()
+
+	if !h.roleTemplatesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	instances, err := h.templateService.ListInstances(ctx, tenantID)
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, instances)
+}
+
+// DetectTenantRoleTemplateDrift compara as funções instanciadas do tenant com os seus modelos
+// Com driftedOnly=true são retornadas apenas as funções com desvio
+func (h *RoleHandler) DetectTenantRoleTemplateDrift(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DetectTenantRoleTemplateDrift")
+	defer span.End()
+
+	if !h.roleTemplatesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	drifts, err := h.templateService.DetectTenantDrift(ctx, tenantID)
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, tenantID, err)
+		return
+	}
+
+	if r.URL.Query().Get("driftedOnly") == "true" {
+		drifted := make([]*model.RoleTemplateDrift, 0, len(drifts))
+		for _, drift := range drifts {
+			if drift.HasDrift {
+				drifted = append(drifted, drift)
+			}
+		}
+		drifts = drifted
+	}
+
+	h.respondWithJSON(w, http.StatusOK, drifts)
+}
+
+// GetRoleTemplateDrift compara uma função instanciada com a versão atual do seu modelo
+func (h *RoleHandler) GetRoleTemplateDrift(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetRoleTemplateDrift")
+	defer span.End()
+
+	if !h.roleTemplatesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	roleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("role.id", roleID.String()),
+	)
+
+	drift, err := h.templateService.DetectDrift(ctx, tenantID, roleID)
+	if err != nil {
+		h.respondWithRoleTemplateError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, drift)
+}
+
+// roleTemplatesEnabled responde 501 quando o serviço de modelos de função não está configurado
+func (h *RoleHandler) roleTemplatesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.templateService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// roleTemplateRequest valida a disponibilidade do serviço e extrai o modelo
+func (h *RoleHandler) roleTemplateRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, bool) {
+	if !h.roleTemplatesEnabled(w, r) {
+		return uuid.Nil, false
+	}
+
+	templateID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTemplateID, nil)
+		return uuid.Nil, false
+	}
+
+	span.SetAttributes(attribute.String("template.id", templateID.String()))
+	return templateID, true
+}
+
+// respondWithRoleTemplateError mapeia os erros dos modelos de função para códigos HTTP apropriados
+func (h *RoleHandler) respondWithRoleTemplateError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar modelo de função")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrRoleTemplateNotFound),
+		errors.Is(err, application.ErrRoleTemplateInstanceNotFound),
+		errors.Is(err, application.ErrRoleNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidRoleTemplate),
+		errors.Is(err, application.ErrRoleTemplatePermissionsUnmapped):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrRoleTemplateInactive),
+		errors.Is(err, application.ErrRoleTemplateNotAvailable):
+		h.respondWithError(w, r, http.StatusUnprocessableEntity, i18n.CodeOperationNotAllowed, err)
+	case errors.Is(err, application.ErrRoleTemplateCodeAlreadyExists),
+		errors.Is(err, application.ErrRoleTemplateAlreadyInstantiated),
+		errors.Is(err, application.ErrRoleCodeAlreadyExists):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrRoleTemplateConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConcurrentModification, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar modelo de função")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,372 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// TenantBrandingRequest representa a identidade visual do tenant autenticado usada nos emails
+type TenantBrandingRequest struct {
+	DisplayName    string `json:"display_name"`
+	LogoURL        string `json:"logo_url,omitempty"`
+	PrimaryColor   string `json:"primary_color,omitempty"`
+	SecondaryColor string `json:"secondary_color,omitempty"`
+	SupportEmail   string `json:"support_email,omitempty"`
+	SupportURL     string `json:"support_url,omitempty"`
+	FooterText     string `json:"footer_text,omitempty"`
+}
+
+// EmailTemplateRequest representa uma nova versão do modelo de um email num idioma
+// O assunto e o texto são modelos text/template e o HTML é sanitizado e compilado como html/template
+type EmailTemplateRequest struct {
+	Kind        model.EmailTemplateKind `json:"kind"`
+	Locale      string                  `json:"locale"`
+	Description string                  `json:"description,omitempty"`
+	Subject     string                  `json:"subject"`
+	HTMLBody    string                  `json:"html_body"`
+	TextBody    string                  `json:"text_body,omitempty"`
+}
+
+// EmailPreviewRequest representa a pré-visualização de um email com valores de exemplo
+// Com template_id é pré-visualizada essa versão; com html_body, o modelo enviado; sem nenhum dos
+// dois, a versão publicada do email e idioma ou o modelo padrão
+type EmailPreviewRequest struct {
+	TemplateID *uuid.UUID              `json:"template_id,omitempty"`
+	Kind       model.EmailTemplateKind `json:"kind,omitempty"`
+	Locale     string                  `json:"locale,omitempty"`
+	Subject    string                  `json:"subject,omitempty"`
+	HTMLBody   string                  `json:"html_body,omitempty"`
+	TextBody   string                  `json:"text_body,omitempty"`
+	// Variables substitui os valores de exemplo das variáveis
+	Variables map[string]string `json:"variables,omitempty"`
+	// Branding pré-visualiza uma identidade visual ainda não gravada
+	Branding *TenantBrandingRequest `json:"branding,omitempty"`
+}
+
+// SetTenantBrandingService configura o serviço da identidade visual e dos modelos de email usado pelo handler
+func (h *RoleHandler) SetTenantBrandingService(tenantBrandingService application.TenantBrandingService) {
+	h.tenantBrandingService = tenantBrandingService
+}
+
+// GetTenantBranding obtém a identidade visual do tenant
+func (h *RoleHandler) GetTenantBranding(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetTenantBranding")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	branding, err := h.tenantBrandingService.GetBranding(ctx, tenantID)
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, branding)
+}
+
+// UpdateTenantBranding altera a identidade visual do tenant
+func (h *RoleHandler) UpdateTenantBranding(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateTenantBranding")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	var req TenantBrandingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+	)
+
+	branding, err := h.tenantBrandingService.UpdateBranding(ctx, &application.UpdateTenantBrandingRequest{
+		TenantID:       tenantID,
+		DisplayName:    req.DisplayName,
+		LogoURL:        req.LogoURL,
+		PrimaryColor:   req.PrimaryColor,
+		SecondaryColor: req.SecondaryColor,
+		SupportEmail:   req.SupportEmail,
+		SupportURL:     req.SupportURL,
+		FooterText:     req.FooterText,
+		UpdatedBy:      actorID,
+	})
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, branding)
+}
+
+// ListEmailTemplateVariables lista as variáveis disponíveis nos modelos de cada email
+func (h *RoleHandler) ListEmailTemplateVariables(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListEmailTemplateVariables")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, h.tenantBrandingService.ListVariables(ctx))
+}
+
+// ListEmailTemplates lista as versões dos modelos de email do tenant
+func (h *RoleHandler) ListEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListEmailTemplates")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	query := r.URL.Query()
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.kind", query.Get("kind")),
+	)
+
+	templates, err := h.tenantBrandingService.ListEmailTemplates(ctx, &application.ListEmailTemplatesRequest{
+		TenantID: tenantID,
+		Kind:     model.EmailTemplateKind(query.Get("kind")),
+		Locale:   query.Get("locale"),
+		Status:   model.EmailTemplateStatus(query.Get("status")),
+	})
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+	if templates == nil {
+		templates = []*model.EmailTemplate{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, templates)
+}
+
+// CreateEmailTemplate grava uma nova versão do modelo de um email como rascunho
+func (h *RoleHandler) CreateEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateEmailTemplate")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	var req EmailTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("email_template.kind", string(req.Kind)),
+		attribute.String("email_template.locale", req.Locale),
+	)
+
+	template, err := h.tenantBrandingService.CreateEmailTemplate(ctx, &application.CreateEmailTemplateRequest{
+		TenantID:    tenantID,
+		Kind:        req.Kind,
+		Locale:      req.Locale,
+		Description: req.Description,
+		Subject:     req.Subject,
+		HTMLBody:    req.HTMLBody,
+		TextBody:    req.TextBody,
+		CreatedBy:   actorID,
+	})
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("email_template.version", template.Version))
+	h.respondWithJSON(w, http.StatusCreated, template)
+}
+
+// GetEmailTemplate obtém uma versão de um modelo de email
+func (h *RoleHandler) GetEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetEmailTemplate")
+	defer span.End()
+
+	tenantID, templateID, ok := h.emailTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	template, err := h.tenantBrandingService.GetEmailTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// PublishEmailTemplate publica uma versão no lugar da versão publicada do mesmo email e idioma
+// Publicar uma versão arquivada repõe-na
+func (h *RoleHandler) PublishEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.PublishEmailTemplate")
+	defer span.End()
+
+	tenantID, templateID, ok := h.emailTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	template, err := h.tenantBrandingService.PublishEmailTemplate(ctx, tenantID, templateID, h.getUserID(r))
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// ArchiveEmailTemplate arquiva uma versão; arquivar a versão publicada repõe o modelo padrão
+func (h *RoleHandler) ArchiveEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ArchiveEmailTemplate")
+	defer span.End()
+
+	tenantID, templateID, ok := h.emailTemplateRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	template, err := h.tenantBrandingService.ArchiveEmailTemplate(ctx, tenantID, templateID, h.getUserID(r))
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, template)
+}
+
+// PreviewEmail produz um email com valores de exemplo e a identidade visual do tenant, sem o enviar
+func (h *RoleHandler) PreviewEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.PreviewEmail")
+	defer span.End()
+
+	if !h.tenantBrandingEnabled(w, r) {
+		return
+	}
+
+	var req EmailPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("email_template.kind", string(req.Kind)),
+	)
+
+	preview := &application.PreviewEmailRequest{
+		TenantID:   tenantID,
+		TemplateID: req.TemplateID,
+		Kind:       req.Kind,
+		Locale:     req.Locale,
+		Subject:    req.Subject,
+		HTMLBody:   req.HTMLBody,
+		TextBody:   req.TextBody,
+		Variables:  req.Variables,
+	}
+	if req.Branding != nil {
+		preview.Branding = &model.TenantBranding{
+			TenantID:       tenantID,
+			DisplayName:    req.Branding.DisplayName,
+			LogoURL:        req.Branding.LogoURL,
+			PrimaryColor:   req.Branding.PrimaryColor,
+			SecondaryColor: req.Branding.SecondaryColor,
+			SupportEmail:   req.Branding.SupportEmail,
+			SupportURL:     req.Branding.SupportURL,
+			FooterText:     req.Branding.FooterText,
+		}
+	}
+
+	rendered, err := h.tenantBrandingService.PreviewEmail(ctx, preview)
+	if err != nil {
+		h.respondWithTenantBrandingError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, rendered)
+}
+
+// emailTemplateRequest valida o serviço e o ID da versão do modelo indicada no caminho
+func (h *RoleHandler) emailTemplateRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.tenantBrandingEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	templateID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidEmailTemplateID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", h.getUserID(r).String()),
+		attribute.String("email_template.id", templateID.String()),
+	)
+	return tenantID, templateID, true
+}
+
+// tenantBrandingEnabled responde 501 quando a identidade visual e os modelos de email não estão configurados
+func (h *RoleHandler) tenantBrandingEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.tenantBrandingService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// respondWithTenantBrandingError mapeia os erros da identidade visual e dos modelos de email para códigos HTTP apropriados
+func (h *RoleHandler) respondWithTenantBrandingError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar identidade visual ou modelos de email")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrEmailTemplateNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidTenantBranding),
+		errors.Is(err, application.ErrInvalidEmailTemplate),
+		errors.Is(err, application.ErrUnknownEmailTemplateVariable),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar identidade visual ou modelos de email")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
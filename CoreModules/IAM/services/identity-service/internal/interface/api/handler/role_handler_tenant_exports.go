@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// TenantExportRequest representa o pedido de exportação dos dados do tenant
+// Sem mercado no corpo, é usado o cabeçalho X-Market; sem tratamento de dados pessoais,
+// aplica-se o exigido pelo mercado
+type TenantExportRequest struct {
+	Market  string `json:"market,omitempty"`
+	Format  string `json:"format,omitempty"`
+	PIIMode string `json:"pii_mode,omitempty"`
+}
+
+// SetTenantExportService configura o serviço de exportação de dados do tenant usado pelo handler
+func (h *RoleHandler) SetTenantExportService(exportService application.TenantExportService) {
+	h.exportService = exportService
+}
+
+// StartTenantExport regista a exportação dos dados IAM do tenant para a sua saída da plataforma
+func (h *RoleHandler) StartTenantExport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.StartTenantExport")
+	defer span.End()
+
+	if !h.tenantExportsEnabled(w, r) {
+		return
+	}
+
+	var req TenantExportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+	}
+	if req.Market == "" {
+		req.Market = r.Header.Get("X-Market")
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("tenant_export.market", req.Market),
+	)
+
+	export, err := h.exportService.Start(ctx, &application.StartTenantExportRequest{
+		TenantID:    tenantID,
+		RequestedBy: actorID,
+		Market:      req.Market,
+		Format:      model.TenantExportFormat(req.Format),
+		PIIMode:     model.TenantExportPIIMode(req.PIIMode),
+	})
+	if err != nil {
+		h.respondWithTenantExportError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, export)
+}
+
+// ListTenantExports lista as exportações do tenant, da mais recente para a mais antiga
+func (h *RoleHandler) ListTenantExports(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListTenantExports")
+	defer span.End()
+
+	if !h.tenantExportsEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	exports, err := h.exportService.List(ctx, tenantID)
+	if err != nil {
+		h.respondWithTenantExportError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, exports)
+}
+
+// GetTenantExport obtém o estado e o progresso por secção de uma exportação
+func (h *RoleHandler) GetTenantExport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetTenantExport")
+	defer span.End()
+
+	tenantID, exportID, ok := h.tenantExportRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.Get(ctx, tenantID, exportID)
+	if err != nil {
+		h.respondWithTenantExportError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, export)
+}
+
+// ResumeTenantExport retoma uma exportação falhada a partir do último lote gravado
+func (h *RoleHandler) ResumeTenantExport(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ResumeTenantExport")
+	defer span.End()
+
+	tenantID, exportID, ok := h.tenantExportRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.Resume(ctx, tenantID, exportID)
+	if err != nil {
+		h.respondWithTenantExportError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, export)
+}
+
+// DownloadTenantExportFile descarrega um ficheiro de dados ou o manifesto de uma exportação concluída
+func (h *RoleHandler) DownloadTenantExportFile(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DownloadTenantExportFile")
+	defer span.End()
+
+	tenantID, exportID, ok := h.tenantExportRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	span.SetAttributes(attribute.String("tenant_export.file", name))
+
+	file, err := h.exportService.OpenFile(ctx, tenantID, exportID, name)
+	if err != nil {
+		h.respondWithTenantExportError(w, r, span, tenantID, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("export_id", exportID.String()).
+			Msg("Erro ao enviar ficheiro da exportação")
+	}
+}
+
+// GetTenantExportSigningKey obtém a chave pública que verifica os manifestos das exportações
+func (h *RoleHandler) GetTenantExportSigningKey(w http.ResponseWriter, r *http.Request) {
+	_, span := h.tracer.Start(r.Context(), "RoleHandler.GetTenantExportSigningKey")
+	defer span.End()
+
+	if !h.tenantExportsEnabled(w, r) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, h.exportService.SigningKey())
+}
+
+// tenantExportsEnabled responde 501 quando a exportação de dados não está configurada
+func (h *RoleHandler) tenantExportsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.exportService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// tenantExportRequest valida a disponibilidade do serviço e extrai o tenant e a exportação
+func (h *RoleHandler) tenantExportRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.tenantExportsEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	exportID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExportID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("tenant_export.id", exportID.String()),
+	)
+	return tenantID, exportID, true
+}
+
+// respondWithTenantExportError mapeia os erros da exportação de dados para códigos HTTP apropriados
+func (h *RoleHandler) respondWithTenantExportError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar exportação de tenant")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrTenantExportNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidTenantExport):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrTenantExportInProgress), errors.Is(err, application.ErrTenantExportNotReady):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	case errors.Is(err, application.ErrTenantExportConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConcurrentModification, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar exportação de tenant")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// TokenExchangeRequest representa um pedido de troca de tokens (RFC 8693, secção 2.1)
+// O pedido é normalmente enviado como application/x-www-form-urlencoded, com os mesmos nomes;
+// scope contém os escopos pedidos separados por espaços
+type TokenExchangeRequest struct {
+	GrantType          string `json:"grant_type"`
+	SubjectToken       string `json:"subject_token"`
+	SubjectTokenType   string `json:"subject_token_type"`
+	ActorToken         string `json:"actor_token"`
+	ActorTokenType     string `json:"actor_token_type"`
+	Audience           string `json:"audience,omitempty"`
+	Resource           string `json:"resource,omitempty"`
+	Scope              string `json:"scope,omitempty"`
+	RequestedTokenType string `json:"requested_token_type,omitempty"`
+}
+
+// OAuthErrorResponse representa uma resposta de erro dos endpoints de emissão de tokens (RFC 6749, secção 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenExchangePolicyRequest representa a criação ou a alteração de uma política de troca de tokens
+// Sem profundidade de delegação, apenas o serviço que pede a troca pode agir em nome do titular
+type TokenExchangePolicyRequest struct {
+	Audience           string   `json:"audience"`
+	Description        string   `json:"description,omitempty"`
+	AllowedActors      []string `json:"allowed_actors"`
+	AllowedScopes      []string `json:"allowed_scopes"`
+	MaxTTLSeconds      int      `json:"max_ttl_seconds"`
+	MaxDelegationDepth int      `json:"max_delegation_depth,omitempty"`
+	Enabled            bool     `json:"enabled"`
+}
+
+// SetTokenExchangeService configura o serviço de troca de tokens usado pelo handler
+func (h *RoleHandler) SetTokenExchangeService(tokenExchangeService application.TokenExchangeService) {
+	h.tokenExchangeService = tokenExchangeService
+}
+
+// ExchangeToken troca o token do titular e o do serviço que age por um token delegado
+// Os erros seguem o formato do RFC 6749 em vez do envelope de erros da API
+func (h *RoleHandler) ExchangeToken(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ExchangeToken")
+	defer span.End()
+
+	if !h.tokenExchangeEnabled(w, r) {
+		return
+	}
+
+	req, err := decodeTokenExchangeRequest(r)
+	if err != nil {
+		h.respondWithOAuthError(w, span, http.StatusBadRequest, model.TokenExchangeErrorInvalidRequest, err)
+		return
+	}
+	req.IPAddress = h.clientIP(r)
+
+	resp, err := h.tokenExchangeService.Exchange(ctx, req)
+	if err != nil {
+		code := model.TokenExchangeErrorCode(err)
+		if code == "" {
+			h.logger.Error().Err(err).Msg("Erro ao processar troca de tokens")
+			h.respondWithOAuthError(w, span, http.StatusInternalServerError, "server_error", nil)
+			return
+		}
+		h.respondWithOAuthError(w, span, http.StatusBadRequest, code, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListTokenExchangePolicies lista as políticas de troca de tokens do tenant
+func (h *RoleHandler) ListTokenExchangePolicies(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListTokenExchangePolicies")
+	defer span.End()
+
+	if !h.tokenExchangeEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	policies, err := h.tokenExchangeService.ListPolicies(ctx, tenantID)
+	if err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policies)
+}
+
+// CreateTokenExchangePolicy cria uma política de troca de tokens no tenant
+func (h *RoleHandler) CreateTokenExchangePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateTokenExchangePolicy")
+	defer span.End()
+
+	if !h.tokenExchangeEnabled(w, r) {
+		return
+	}
+
+	var req TokenExchangePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("token_exchange.audience", req.Audience),
+	)
+
+	policy, err := h.tokenExchangeService.CreatePolicy(ctx, req.toApplication(tenantID, uuid.Nil, actorID))
+	if err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, policy)
+}
+
+// GetTokenExchangePolicy obtém uma política de troca de tokens do tenant
+func (h *RoleHandler) GetTokenExchangePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetTokenExchangePolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.tokenExchangePolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	policy, err := h.tokenExchangeService.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// UpdateTokenExchangePolicy substitui uma política de troca de tokens do tenant
+func (h *RoleHandler) UpdateTokenExchangePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateTokenExchangePolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.tokenExchangePolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req TokenExchangePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	policy, err := h.tokenExchangeService.UpdatePolicy(ctx, req.toApplication(tenantID, policyID, actorID))
+	if err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, policy)
+}
+
+// DeleteTokenExchangePolicy remove uma política de troca de tokens do tenant
+func (h *RoleHandler) DeleteTokenExchangePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DeleteTokenExchangePolicy")
+	defer span.End()
+
+	tenantID, policyID, ok := h.tokenExchangePolicyRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	if err := h.tokenExchangeService.DeletePolicy(ctx, tenantID, policyID, actorID); err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTokenExchangeEvents lista o registo de auditoria das trocas de tokens do tenant
+// Filtros opcionais: subject, actor, audience, outcome, since (RFC 3339) e limit
+func (h *RoleHandler) ListTokenExchangeEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListTokenExchangeEvents")
+	defer span.End()
+
+	if !h.tokenExchangeEnabled(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.TokenExchangeEventFilter{
+		Subject:  query.Get("subject"),
+		Actor:    query.Get("actor"),
+		Audience: query.Get("audience"),
+		Outcome:  model.TokenExchangeOutcome(query.Get("outcome")),
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTimestamp, nil)
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("filter.outcome", string(filter.Outcome)),
+	)
+
+	events, err := h.tokenExchangeService.ListEvents(ctx, tenantID, filter)
+	if err != nil {
+		h.respondWithTokenExchangeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, events)
+}
+
+// toApplication converte o corpo do pedido no pedido do serviço
+func (req *TokenExchangePolicyRequest) toApplication(tenantID, policyID, actorID uuid.UUID) *application.SaveTokenExchangePolicyRequest {
+	return &application.SaveTokenExchangePolicyRequest{
+		TenantID:           tenantID,
+		PolicyID:           policyID,
+		Audience:           req.Audience,
+		Description:        req.Description,
+		AllowedActors:      req.AllowedActors,
+		AllowedScopes:      req.AllowedScopes,
+		MaxTTLSeconds:      req.MaxTTLSeconds,
+		MaxDelegationDepth: req.MaxDelegationDepth,
+		Enabled:            req.Enabled,
+		ActorID:            actorID,
+	}
+}
+
+// decodeTokenExchangeRequest lê o pedido de troca em formulário ou em JSON
+// Cada parâmetro do formulário pode ser indicado uma única vez
+func decodeTokenExchangeRequest(r *http.Request) (*application.TokenExchangeRequest, error) {
+	var body TokenExchangeRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errors.New("corpo do pedido inválido")
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, errors.New("formulário do pedido inválido")
+		}
+		for name, values := range r.PostForm {
+			if len(values) > 1 {
+				return nil, errors.New("parâmetro repetido: " + name)
+			}
+		}
+		body = TokenExchangeRequest{
+			GrantType:          r.PostForm.Get("grant_type"),
+			SubjectToken:       r.PostForm.Get("subject_token"),
+			SubjectTokenType:   r.PostForm.Get("subject_token_type"),
+			ActorToken:         r.PostForm.Get("actor_token"),
+			ActorTokenType:     r.PostForm.Get("actor_token_type"),
+			Audience:           r.PostForm.Get("audience"),
+			Resource:           r.PostForm.Get("resource"),
+			Scope:              r.PostForm.Get("scope"),
+			RequestedTokenType: r.PostForm.Get("requested_token_type"),
+		}
+	}
+
+	return &application.TokenExchangeRequest{
+		GrantType:          body.GrantType,
+		SubjectToken:       body.SubjectToken,
+		SubjectTokenType:   body.SubjectTokenType,
+		ActorToken:         body.ActorToken,
+		ActorTokenType:     body.ActorTokenType,
+		Audience:           body.Audience,
+		Resource:           body.Resource,
+		Scopes:             strings.Fields(body.Scope),
+		RequestedTokenType: body.RequestedTokenType,
+	}, nil
+}
+
+// tokenExchangeEnabled responde 501 quando a troca de tokens não está configurada
+func (h *RoleHandler) tokenExchangeEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.tokenExchangeService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// tokenExchangePolicyRequest valida a disponibilidade do serviço e extrai o tenant e a política
+func (h *RoleHandler) tokenExchangePolicyRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.tokenExchangeEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	policyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidTokenExchangePolicyID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("token_exchange_policy.id", policyID.String()),
+	)
+	return tenantID, policyID, true
+}
+
+// respondWithOAuthError envia uma resposta de erro no formato do RFC 6749
+func (h *RoleHandler) respondWithOAuthError(w http.ResponseWriter, span trace.Span, status int, code string, cause error) {
+	span.SetStatus(codes.Error, "Pedido OAuth recusado")
+	span.SetAttributes(attribute.String("oauth.error", code))
+
+	resp := OAuthErrorResponse{Error: code}
+	if cause != nil {
+		span.RecordError(cause)
+		resp.ErrorDescription = cause.Error()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.respondWithJSON(w, status, resp)
+}
+
+// respondWithTokenExchangeError mapeia os erros das políticas de troca de tokens para códigos HTTP apropriados
+func (h *RoleHandler) respondWithTokenExchangeError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar política de troca de tokens")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrTokenExchangePolicyNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidTokenExchangePolicy),
+		errors.Is(err, application.ErrInvalidTokenExchangeFilter),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrTokenExchangePolicyConflict):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar política de troca de tokens")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
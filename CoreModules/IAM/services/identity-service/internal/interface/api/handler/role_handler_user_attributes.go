@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// UserAttributeSchemaRequest representa a criação ou a alteração de um esquema de atributo
+// Na alteração, o nome e o tipo têm de ser os do esquema atual
+type UserAttributeSchemaRequest struct {
+	Name        string                  `json:"name"`
+	DisplayName string                  `json:"display_name,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Type        model.UserAttributeType `json:"type"`
+	Required    bool                    `json:"required"`
+	EnumValues  []string                `json:"enum_values,omitempty"`
+	Pattern     string                  `json:"pattern,omitempty"`
+	MaxLength   int                     `json:"max_length,omitempty"`
+	MinValue    *float64                `json:"min_value,omitempty"`
+	MaxValue    *float64                `json:"max_value,omitempty"`
+	ClaimName   string                  `json:"claim_name,omitempty"`
+}
+
+// UserAttributesRequest representa a substituição dos atributos de um usuário
+type UserAttributesRequest struct {
+	Values map[string]interface{} `json:"values"`
+}
+
+// UserClaimsResponse representa as claims personalizadas a emitir nos tokens do usuário
+type UserClaimsResponse struct {
+	Claims map[string]interface{} `json:"claims"`
+}
+
+// SetUserAttributeService configura o serviço dos atributos dos usuários usado pelo handler
+func (h *RoleHandler) SetUserAttributeService(userAttributeService application.UserAttributeService) {
+	h.userAttributeService = userAttributeService
+}
+
+// ListUserAttributeSchemas lista os esquemas dos atributos dos usuários do tenant
+func (h *RoleHandler) ListUserAttributeSchemas(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.ListUserAttributeSchemas")
+	defer span.End()
+
+	if !h.userAttributesEnabled(w, r) {
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+	schemas, err := h.userAttributeService.ListSchemas(ctx, tenantID)
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, schemas)
+}
+
+// CreateUserAttributeSchema cria um esquema de atributo no tenant
+func (h *RoleHandler) CreateUserAttributeSchema(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.CreateUserAttributeSchema")
+	defer span.End()
+
+	if !h.userAttributesEnabled(w, r) {
+		return
+	}
+
+	var req UserAttributeSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	tenantID := h.getTenantID(r)
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("actor.id", actorID.String()),
+		attribute.String("user_attribute.name", req.Name),
+	)
+
+	schema, err := h.userAttributeService.CreateSchema(ctx, userAttributeSchemaRequest(&req, tenantID, uuid.Nil, actorID))
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, schema)
+}
+
+// GetUserAttributeSchema obtém um esquema de atributo do tenant
+func (h *RoleHandler) GetUserAttributeSchema(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetUserAttributeSchema")
+	defer span.End()
+
+	tenantID, schemaID, ok := h.userAttributeSchemaRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	schema, err := h.userAttributeService.GetSchema(ctx, tenantID, schemaID)
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, schema)
+}
+
+// UpdateUserAttributeSchema altera as restrições, a obrigatoriedade e a claim de um esquema
+func (h *RoleHandler) UpdateUserAttributeSchema(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.UpdateUserAttributeSchema")
+	defer span.End()
+
+	tenantID, schemaID, ok := h.userAttributeSchemaRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	var req UserAttributeSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	schema, err := h.userAttributeService.UpdateSchema(ctx, userAttributeSchemaRequest(&req, tenantID, schemaID, actorID))
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, schema)
+}
+
+// DeleteUserAttributeSchema remove um esquema e o atributo de todos os usuários do tenant
+func (h *RoleHandler) DeleteUserAttributeSchema(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.DeleteUserAttributeSchema")
+	defer span.End()
+
+	tenantID, schemaID, ok := h.userAttributeSchemaRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(attribute.String("actor.id", actorID.String()))
+
+	if err := h.userAttributeService.DeleteSchema(ctx, tenantID, schemaID, actorID); err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserAttributes obtém os atributos personalizados de um usuário
+func (h *RoleHandler) GetUserAttributes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetUserAttributes")
+	defer span.End()
+
+	tenantID, userID, ok := h.userAttributesRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	attributes, err := h.userAttributeService.GetUserAttributes(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, attributes)
+}
+
+// SetUserAttributes substitui os atributos personalizados de um usuário, validados pelos esquemas do tenant
+func (h *RoleHandler) SetUserAttributes(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.SetUserAttributes")
+	defer span.End()
+
+	tenantID, userID, ok := h.userAttributesRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	// Os números são lidos sem perda de precisão, para que os inteiros grandes sejam validados tal como enviados
+	var req UserAttributesRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
+		return
+	}
+
+	actorID := h.getUserID(r)
+	span.SetAttributes(
+		attribute.String("actor.id", actorID.String()),
+		attribute.Int("user_attribute.count", len(req.Values)),
+	)
+
+	attributes, err := h.userAttributeService.SetUserAttributes(ctx, &application.SetUserAttributesRequest{
+		TenantID: tenantID,
+		UserID:   userID,
+		Values:   req.Values,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, attributes)
+}
+
+// GetUserClaims obtém as claims personalizadas a emitir nos tokens do usuário, usada pelos emissores de tokens
+func (h *RoleHandler) GetUserClaims(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "RoleHandler.GetUserClaims")
+	defer span.End()
+
+	tenantID, userID, ok := h.userAttributesRequest(w, r, span)
+	if !ok {
+		return
+	}
+
+	claims, err := h.userAttributeService.ResolveClaims(ctx, tenantID, userID)
+	if err != nil {
+		h.respondWithUserAttributeError(w, r, span, tenantID, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, UserClaimsResponse{Claims: claims})
+}
+
+// userAttributeSchemaRequest converte o corpo do pedido no pedido do serviço
+func userAttributeSchemaRequest(req *UserAttributeSchemaRequest, tenantID, schemaID, actorID uuid.UUID) *application.SaveUserAttributeSchemaRequest {
+	return &application.SaveUserAttributeSchemaRequest{
+		TenantID:    tenantID,
+		SchemaID:    schemaID,
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Type:        req.Type,
+		Required:    req.Required,
+		EnumValues:  req.EnumValues,
+		Pattern:     req.Pattern,
+		MaxLength:   req.MaxLength,
+		MinValue:    req.MinValue,
+		MaxValue:    req.MaxValue,
+		ClaimName:   req.ClaimName,
+		ActorID:     actorID,
+	}
+}
+
+// userAttributesEnabled responde 501 quando os atributos dos usuários não estão configurados
+func (h *RoleHandler) userAttributesEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.userAttributeService == nil {
+		h.respondWithError(w, r, http.StatusNotImplemented, i18n.CodeNotImplemented, nil)
+		return false
+	}
+	return true
+}
+
+// userAttributeSchemaRequest valida a disponibilidade do serviço e extrai o tenant e o esquema
+func (h *RoleHandler) userAttributeSchemaRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.userAttributesEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	schemaID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserAttributeSchemaID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user_attribute.schema_id", schemaID.String()),
+	)
+	return tenantID, schemaID, true
+}
+
+// userAttributesRequest valida a disponibilidade do serviço e extrai o tenant e o usuário
+func (h *RoleHandler) userAttributesRequest(w http.ResponseWriter, r *http.Request, span trace.Span) (uuid.UUID, uuid.UUID, bool) {
+	if !h.userAttributesEnabled(w, r) {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tenantID := h.getTenantID(r)
+	userID, err := uuid.Parse(mux.Vars(r)["userId"])
+	if err != nil {
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	span.SetAttributes(
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("user.id", userID.String()),
+	)
+	return tenantID, userID, true
+}
+
+// respondWithUserAttributeError mapeia os erros dos atributos dos usuários para códigos HTTP apropriados
+func (h *RoleHandler) respondWithUserAttributeError(w http.ResponseWriter, r *http.Request, span trace.Span, tenantID uuid.UUID, err error) {
+	span.SetStatus(codes.Error, "Falha ao processar atributos dos usuários")
+	span.RecordError(err)
+
+	switch {
+	case errors.Is(err, application.ErrUserAttributeSchemaNotFound),
+		errors.Is(err, model.ErrUserNotFound):
+		h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
+	case errors.Is(err, application.ErrInvalidUserAttributeSchema),
+		errors.Is(err, application.ErrInvalidUserAttributes),
+		errors.Is(err, model.ErrInvalidTenantID):
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
+	case errors.Is(err, application.ErrUserAttributeSchemaConflict),
+		errors.Is(err, application.ErrUserAttributeSchemaLimit):
+		h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
+	default:
+		h.logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Msg("Erro ao processar atributos dos usuários")
+		h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
+	}
+}
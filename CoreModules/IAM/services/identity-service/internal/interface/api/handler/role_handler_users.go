@@ -11,9 +11,21 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
 	"innovabiz/iam/identity-service/internal/domain/model"
 )
 
+// UserRoleAssignmentRequest representa o modelo de dados para atribuição de um usuário a uma função
+// A data de expiração aceita RFC3339; vazia significa sem expiração
+type UserRoleAssignmentRequest struct {
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// RoleMembershipResponse representa o resultado da verificação de um usuário numa função
+type RoleMembershipResponse struct {
+	IsInRole bool `json:"isInRole"`
+}
+
 // UserResponse representa o modelo de dados para retorno de um usuário
 type UserResponse struct {
 	ID          uuid.UUID              `json:"id"`
@@ -90,11 +102,11 @@ func toUserRoleResponseList(usersWithExpiration []*model.UserWithExpiration) []U
 }
 
 // toRoleUserResponseList converte uma lista de modelos de domínio RoleWithExpiration para []RoleUserResponse
-func toRoleUserResponseList(rolesWithExpiration []*model.RoleWithExpiration) []RoleUserResponse {
+func toRoleUserResponseList(rolesWithExpiration []*model.RoleWithExpiration, access *model.RoleMetadataAccess) []RoleUserResponse {
 	responseList := make([]RoleUserResponse, len(rolesWithExpiration))
 	for i, roleWithExp := range rolesWithExpiration {
 		responseList[i] = RoleUserResponse{
-			Role:      toRoleResponse(roleWithExp.Role),
+			Role:      toRoleResponse(roleWithExp.Role, access),
 			ExpiresAt: roleWithExp.ExpiresAt,
 			AssignedAt: roleWithExp.AssignedAt,
 			AssignedBy: roleWithExp.AssignedBy,
@@ -112,7 +124,7 @@ func (h *RoleHandler) GetRoleUsers(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	roleID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidID, nil)
 		return
 	}
 
@@ -137,13 +149,13 @@ func (h *RoleHandler) GetRoleUsers(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Msg("Erro ao obter usuários da função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -175,7 +187,7 @@ func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
@@ -200,17 +212,22 @@ func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("user_id", userID.String()).
 				Msg("Erro ao obter funções do usuário")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Calcular total de páginas
 	totalPages := totalCount / int64(pagination.PageSize)
 	if totalCount%int64(pagination.PageSize) > 0 {
@@ -219,7 +236,7 @@ func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 
 	// Responder com a lista de funções e informações de paginação
 	h.respondWithJSON(w, http.StatusOK, response{
-		Data: toRoleUserResponseList(roles),
+		Data: toRoleUserResponseList(roles, access),
 		Pagination: &paginationResponse{
 			Page:       pagination.Page,
 			PageSize:   pagination.PageSize,
@@ -238,7 +255,7 @@ func (h *RoleHandler) GetAllUserRoles(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
@@ -259,19 +276,24 @@ func (h *RoleHandler) GetAllUserRoles(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("user_id", userID.String()).
 				Msg("Erro ao obter todas as funções do usuário")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
+	access, ok := h.roleMetadataAccess(w, r, span)
+	if !ok {
+		return
+	}
+
 	// Responder com a lista completa de funções
-	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles))
+	h.respondWithJSON(w, http.StatusOK, toRoleResponseList(roles, access))
 }
 
 // AssignUserToRole atribui um usuário a uma função
@@ -285,26 +307,22 @@ func (h *RoleHandler) AssignUserToRole(w http.ResponseWriter, r *http.Request) {
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
 	// Extrair parâmetros adicionais da requisição
-	type assignRequest struct {
-		ExpiresAt string `json:"expiresAt,omitempty"`
-	}
-
-	var req assignRequest
+	var req UserRoleAssignmentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != http.ErrBodyReadCloser {
 		span.SetStatus(codes.Error, "Falha ao decodificar requisição")
 		span.RecordError(err)
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
 		return
 	}
 
@@ -313,7 +331,7 @@ func (h *RoleHandler) AssignUserToRole(w http.ResponseWriter, r *http.Request) {
 	if req.ExpiresAt != "" {
 		parsedTime, err := h.parseExpirationTime(req.ExpiresAt)
 		if err != nil {
-			h.respondWithError(w, http.StatusBadRequest, "invalid_expiration", "Formato de data de expiração inválido")
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExpiration, nil)
 			return
 		}
 		expiresAt = parsedTime
@@ -335,21 +353,26 @@ func (h *RoleHandler) AssignUserToRole(w http.ResponseWriter, r *http.Request) {
 		span.SetStatus(codes.Error, "Falha ao atribuir usuário à função")
 		span.RecordError(err)
 
+		// Uma concessão fora dos limites de permissões já foi registada como incidente de segurança
+		if h.respondWithPermissionBoundaryViolation(w, r, err) {
+			return
+		}
+
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		case *application.DuplicateResourceError:
-			h.respondWithError(w, http.StatusConflict, "conflict", err.Error())
+			h.respondWithError(w, r, http.StatusConflict, i18n.CodeConflict, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Str("user_id", userID.String()).
 				Msg("Erro ao atribuir usuário à função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -369,26 +392,22 @@ func (h *RoleHandler) UpdateUserRoleExpiration(w http.ResponseWriter, r *http.Re
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
 	// Extrair parâmetros adicionais da requisição
-	type updateExpirationRequest struct {
-		ExpiresAt string `json:"expiresAt,omitempty"`
-	}
-
-	var req updateExpirationRequest
+	var req UserRoleAssignmentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		span.SetStatus(codes.Error, "Falha ao decodificar requisição")
 		span.RecordError(err)
-		h.respondWithError(w, http.StatusBadRequest, "invalid_request", "Formato de requisição inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRequest, nil)
 		return
 	}
 
@@ -400,7 +419,7 @@ func (h *RoleHandler) UpdateUserRoleExpiration(w http.ResponseWriter, r *http.Re
 	} else {
 		parsedTime, err := h.parseExpirationTime(req.ExpiresAt)
 		if err != nil {
-			h.respondWithError(w, http.StatusBadRequest, "invalid_expiration", "Formato de data de expiração inválido")
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidExpiration, nil)
 			return
 		}
 		expiresAt = parsedTime
@@ -425,16 +444,16 @@ func (h *RoleHandler) UpdateUserRoleExpiration(w http.ResponseWriter, r *http.Re
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		case *application.ValidationError:
-			h.respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+			h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeValidationError, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Str("user_id", userID.String()).
 				Msg("Erro ao atualizar expiração da atribuição do usuário")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -454,13 +473,13 @@ func (h *RoleHandler) RemoveUserFromRole(w http.ResponseWriter, r *http.Request)
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
@@ -479,14 +498,14 @@ func (h *RoleHandler) RemoveUserFromRole(w http.ResponseWriter, r *http.Request)
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch err.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", err.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, err)
 		default:
 			h.logger.Error().Err(err).
 				Str("tenant_id", tenantID.String()).
 				Str("role_id", roleID.String()).
 				Str("user_id", userID.String()).
 				Msg("Erro ao remover usuário da função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
@@ -505,13 +524,13 @@ func (h *RoleHandler) CheckUserInRole(w http.ResponseWriter, r *http.Request) {
 	
 	roleID, err := uuid.Parse(vars["roleId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_role_id", "ID da função inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidRoleID, nil)
 		return
 	}
 	
 	userID, err := uuid.Parse(vars["userId"])
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid_user_id", "ID do usuário inválido")
+		h.respondWithError(w, r, http.StatusBadRequest, i18n.CodeInvalidUserID, nil)
 		return
 	}
 
@@ -543,7 +562,7 @@ func (h *RoleHandler) CheckUserInRole(w http.ResponseWriter, r *http.Request) {
 		// Mapear erros específicos do domínio para códigos HTTP apropriados
 		switch checkErr.(type) {
 		case *application.ResourceNotFoundError:
-			h.respondWithError(w, http.StatusNotFound, "not_found", checkErr.Error())
+			h.respondWithError(w, r, http.StatusNotFound, i18n.CodeNotFound, checkErr)
 		default:
 			h.logger.Error().Err(checkErr).
 				Str("tenant_id", tenantID.String()).
@@ -552,13 +571,11 @@ func (h *RoleHandler) CheckUserInRole(w http.ResponseWriter, r *http.Request) {
 				Bool("direct_only", directOnly).
 				Bool("include_expired", includeExpired).
 				Msg("Erro ao verificar usuário na função")
-			h.respondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno ao processar a requisição")
+			h.respondWithError(w, r, http.StatusInternalServerError, i18n.CodeInternalError, nil)
 		}
 		return
 	}
 
 	// Responder com o resultado da verificação
-	h.respondWithJSON(w, http.StatusOK, map[string]bool{
-		"isInRole": isInRole,
-	})
+	h.respondWithJSON(w, http.StatusOK, RoleMembershipResponse{IsInRole: isInRole})
 }
\ No newline at end of file
@@ -300,6 +300,90 @@ func TestCreateRole(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestRoleErrorLocalization verifica que o código de erro é estável entre idiomas e a mensagem segue o Accept-Language
+func TestRoleErrorLocalization(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		body            string
+		acceptLanguage  string
+		expectedCode    string
+		expectedMessage string
+		expectedLocale  string
+	}{
+		{
+			name:            "corpo inválido no idioma padrão",
+			method:          http.MethodPost,
+			path:            "/roles",
+			body:            "{",
+			expectedCode:    "invalid_request",
+			expectedMessage: "Formato de requisição inválido",
+			expectedLocale:  "pt-BR",
+		},
+		{
+			name:            "código da função ausente em inglês",
+			method:          http.MethodPost,
+			path:            "/roles",
+			body:            `{"name":"TestRole","type":"CUSTOM"}`,
+			acceptLanguage:  "en-US,en;q=0.9",
+			expectedCode:    "invalid_request",
+			expectedMessage: "Role code is required",
+			expectedLocale:  "en",
+		},
+		{
+			name:            "nome da função ausente em português de Angola",
+			method:          http.MethodPost,
+			path:            "/roles",
+			body:            `{"code":"TEST_ROLE","type":"CUSTOM"}`,
+			acceptLanguage:  "pt-AO",
+			expectedCode:    "invalid_request",
+			expectedMessage: "O nome da função é obrigatório",
+			expectedLocale:  "pt-PT",
+		},
+		{
+			name:            "ID da função fonte inválido em francês",
+			method:          http.MethodPost,
+			path:            "/roles/not-a-uuid/clone",
+			body:            `{}`,
+			acceptLanguage:  "fr",
+			expectedCode:    "invalid_id",
+			expectedMessage: "Identifiant du rôle source invalide",
+			expectedLocale:  "fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService, _, router, _, _ := setupTest()
+
+			req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.expectedLocale, rr.Header().Get("Content-Language"))
+
+			var responseBody map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+			assert.Equal(t, float64(http.StatusBadRequest), responseBody["status"])
+			assert.Equal(t, tt.expectedCode, responseBody["code"])
+			assert.Equal(t, tt.expectedMessage, responseBody["message"])
+			assert.Equal(t, tt.expectedLocale, responseBody["locale"])
+
+			// A validação falha antes de chegar ao serviço
+			mockService.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "CloneRole", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // TestGetRole testa a obtenção de uma função por ID
 func TestGetRole(t *testing.T) {
 	// Configuração
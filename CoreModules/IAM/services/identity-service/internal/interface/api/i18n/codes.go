@@ -0,0 +1,73 @@
+package i18n
+
+// Code identifica um erro da API de forma estável entre idiomas
+type Code string
+
+// Códigos de erro da API de funções
+const (
+	CodeInvalidRequest                    Code = "invalid_request"
+	CodeInvalidID                         Code = "invalid_id"
+	CodeInvalidRoleID                     Code = "invalid_role_id"
+	CodeInvalidUserID                     Code = "invalid_user_id"
+	CodeInvalidPermissionID               Code = "invalid_permission_id"
+	CodeInvalidParentID                   Code = "invalid_parent_id"
+	CodeInvalidChildID                    Code = "invalid_child_id"
+	CodeInvalidExpiration                 Code = "invalid_expiration"
+	CodeInvalidTimestamp                  Code = "invalid_timestamp"
+	CodeInvalidAccessRequestID            Code = "invalid_access_request_id"
+	CodeInvalidApproverID                 Code = "invalid_approver_id"
+	CodeInvalidTemplateID                 Code = "invalid_template_id"
+	CodeInvalidSAMLProviderID             Code = "invalid_saml_provider_id"
+	CodeInvalidIncidentID                 Code = "invalid_incident_id"
+	CodeInvalidExportID                   Code = "invalid_export_id"
+	CodeInvalidSuggestionID               Code = "invalid_suggestion_id"
+	CodeInvalidNetworkPolicyID            Code = "invalid_network_policy_id"
+	CodeInvalidBreakGlassID               Code = "invalid_break_glass_id"
+	CodeInvalidEmergencyActivationID      Code = "invalid_emergency_activation_id"
+	CodeInvalidAccountLinkCandidateID     Code = "invalid_account_link_candidate_id"
+	CodeInvalidLinkedIdentityID           Code = "invalid_linked_identity_id"
+	CodeInvalidLegalDocumentID            Code = "invalid_legal_document_id"
+	CodeInvalidTokenExchangePolicyID      Code = "invalid_token_exchange_policy_id"
+	CodeInvalidServiceAccountID           Code = "invalid_service_account_id"
+	CodeInvalidServiceAccountCredentialID Code = "invalid_service_account_credential_id"
+	CodeInvalidRecertificationCampaignID  Code = "invalid_recertification_campaign_id"
+	CodeInvalidRecertificationItemID      Code = "invalid_recertification_item_id"
+	CodeInvalidUserAttributeSchemaID      Code = "invalid_user_attribute_schema_id"
+	CodeInvalidNotificationTemplateID     Code = "invalid_notification_template_id"
+	CodeInvalidPermissionBoundaryID       Code = "invalid_permission_boundary_id"
+	CodeInvalidBulkUserJobID              Code = "invalid_bulk_user_job_id"
+	CodeInvalidEmailTemplateID            Code = "invalid_email_template_id"
+	CodeInvalidDeviceID                   Code = "invalid_device_id"
+	CodeValidationError                   Code = "validation_error"
+	CodeNotFound                          Code = "not_found"
+	CodeForbidden                         Code = "forbidden"
+	CodeConflict                          Code = "conflict"
+	CodeNotAssigned                       Code = "not_assigned"
+	CodeConcurrentModification            Code = "concurrent_modification"
+	CodeOperationNotAllowed               Code = "operation_not_allowed"
+	CodeResourceInUse                     Code = "resource_in_use"
+	CodeIncompatibleTypes                 Code = "incompatible_types"
+	CodeCyclicReference                   Code = "cyclic_reference"
+	CodePermissionBoundaryExceeded        Code = "permission_boundary_exceeded"
+	CodeAuthenticationFailed              Code = "authentication_failed"
+	CodeInvalidLoginToken                 Code = "invalid_login_token"
+	CodeInvalidLoginResponseToken         Code = "invalid_login_response_token"
+	CodeReauthenticationRequired          Code = "reauthentication_required"
+	CodeTooManyRequests                   Code = "too_many_requests"
+	CodeNotImplemented                    Code = "not_implemented"
+	CodeInternalError                     Code = "internal_error"
+)
+
+// MessageKey identifica uma mensagem mais específica do que a mensagem padrão do código de erro
+// O código devolvido ao cliente não muda; apenas o texto traduzido é mais preciso
+type MessageKey string
+
+// Mensagens específicas dos códigos invalid_request e invalid_id
+const (
+	MessageRoleCodeRequired    MessageKey = "role_code_required"
+	MessageRoleNameRequired    MessageKey = "role_name_required"
+	MessageRoleTypeRequired    MessageKey = "role_type_required"
+	MessageNewRoleCodeRequired MessageKey = "new_role_code_required"
+	MessageNewRoleNameRequired MessageKey = "new_role_name_required"
+	MessageInvalidSourceRoleID MessageKey = "invalid_source_role_id"
+)
@@ -0,0 +1,218 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Idiomas suportados pela API
+const (
+	LocalePtBR = "pt-BR"
+	LocalePtPT = "pt-PT"
+	LocaleEn   = "en"
+	LocaleFr   = "fr"
+	LocaleEs   = "es"
+
+	// DefaultLocale é usado quando o cliente não indica um idioma suportado
+	DefaultLocale = LocalePtBR
+)
+
+// SupportedLocales lista os idiomas com catálogo de mensagens
+var SupportedLocales = []string{LocalePtBR, LocalePtPT, LocaleEn, LocaleFr, LocaleEs}
+
+// regionalLocales mapeia variantes regionais para o catálogo mais próximo
+var regionalLocales = map[string]string{
+	"pt":    LocalePtBR,
+	"pt-ao": LocalePtPT,
+	"pt-mz": LocalePtPT,
+	"pt-cv": LocalePtPT,
+	"pt-gw": LocalePtPT,
+	"pt-st": LocalePtPT,
+	"pt-tl": LocalePtPT,
+}
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// Bundle contém os catálogos de mensagens por idioma
+type Bundle struct {
+	catalogs map[string]map[Code]string
+}
+
+// localeKey é a chave do idioma no contexto da requisição
+type localeKey struct{}
+
+// Default é o bundle carregado a partir dos catálogos embutidos
+var Default = MustLoadBundle()
+
+// MustLoadBundle carrega os catálogos embutidos e falha se algum estiver inválido
+func MustLoadBundle() *Bundle {
+	bundle, err := LoadBundle()
+	if err != nil {
+		panic(err)
+	}
+	return bundle
+}
+
+// LoadBundle carrega os catálogos embutidos de todos os idiomas suportados
+func LoadBundle() (*Bundle, error) {
+	bundle := &Bundle{catalogs: make(map[string]map[Code]string)}
+
+	for _, locale := range SupportedLocales {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", locale+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler catálogo %s: %w", locale, err)
+		}
+
+		catalog := make(map[Code]string)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("falha ao interpretar catálogo %s: %w", locale, err)
+		}
+		bundle.catalogs[locale] = catalog
+	}
+
+	return bundle, nil
+}
+
+// Message retorna a mensagem do código no idioma indicado
+// Códigos sem tradução usam o idioma padrão e, em último caso, o próprio código
+func (b *Bundle) Message(locale string, code Code, args ...interface{}) string {
+	template, ok := b.catalogs[locale][code]
+	if !ok {
+		template, ok = b.catalogs[DefaultLocale][code]
+	}
+	if !ok {
+		return string(code)
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(template, args...)
+	}
+	return template
+}
+
+// Text retorna a mensagem específica no idioma indicado, com as mesmas regras de recurso de Message
+func (b *Bundle) Text(locale string, key MessageKey, args ...interface{}) string {
+	return b.Message(locale, Code(key), args...)
+}
+
+// MissingCodes retorna os códigos presentes no idioma padrão e ausentes no idioma indicado
+func (b *Bundle) MissingCodes(locale string) []Code {
+	var missing []Code
+	for code := range b.catalogs[DefaultLocale] {
+		if _, ok := b.catalogs[locale][code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	return missing
+}
+
+// Negotiate escolhe o idioma suportado de maior preferência no cabeçalho Accept-Language
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+
+	// Ordenação estável preserva a ordem do cliente entre qualidades iguais
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, pref := range preferences {
+		if locale, ok := matchLocale(pref.tag); ok {
+			return locale
+		}
+	}
+
+	return DefaultLocale
+}
+
+// matchLocale associa uma etiqueta de idioma a um catálogo suportado
+func matchLocale(tag string) (string, bool) {
+	if tag == "*" {
+		return DefaultLocale, true
+	}
+
+	for _, locale := range SupportedLocales {
+		if strings.EqualFold(tag, locale) {
+			return locale, true
+		}
+	}
+
+	if locale, ok := regionalLocales[tag]; ok {
+		return locale, true
+	}
+
+	// Variantes regionais sem catálogo próprio usam o idioma base (ex.: en-US -> en)
+	base := strings.SplitN(tag, "-", 2)[0]
+	if locale, ok := regionalLocales[base]; ok {
+		return locale, true
+	}
+	for _, locale := range SupportedLocales {
+		if strings.EqualFold(base, locale) {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
+
+// WithLocale retorna um contexto com o idioma da requisição
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext retorna o idioma armazenado no contexto
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// FromRequest retorna o idioma da requisição, negociando-o se o middleware não estiver ativo
+func FromRequest(r *http.Request) string {
+	if locale, ok := LocaleFromContext(r.Context()); ok {
+		return locale
+	}
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Middleware negocia o idioma da requisição e o disponibiliza no contexto
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := Negotiate(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), locale)))
+	})
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+)
+
+// TestNegotiate verifica a escolha do idioma a partir do cabeçalho Accept-Language
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"sem cabeçalho usa o padrão", "", i18n.LocalePtBR},
+		{"correspondência exata", "fr", i18n.LocaleFr},
+		{"variante regional usa o idioma base", "en-US,en;q=0.9", i18n.LocaleEn},
+		{"português de Angola usa pt-PT", "pt-AO", i18n.LocalePtPT},
+		{"português genérico usa pt-BR", "pt", i18n.LocalePtBR},
+		{"respeita a qualidade", "es;q=0.5,fr;q=0.8", i18n.LocaleFr},
+		{"ignora idiomas não suportados", "de-DE,ja;q=0.9,es;q=0.1", i18n.LocaleEs},
+		{"ignora qualidade zero", "en;q=0,fr", i18n.LocaleFr},
+		{"curinga usa o padrão", "de,*;q=0.5", i18n.LocalePtBR},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, i18n.Negotiate(tc.acceptLanguage))
+		})
+	}
+}
+
+// TestCatalogsComplete verifica que todos os catálogos traduzem todos os códigos
+func TestCatalogsComplete(t *testing.T) {
+	bundle, err := i18n.LoadBundle()
+	require.NoError(t, err)
+
+	for _, locale := range i18n.SupportedLocales {
+		assert.Empty(t, bundle.MissingCodes(locale), "catálogo %s incompleto", locale)
+	}
+
+	assert.Equal(t, "Invalid role ID", bundle.Message(i18n.LocaleEn, i18n.CodeInvalidRoleID))
+	assert.Equal(t, "ID do utilizador inválido", bundle.Message(i18n.LocalePtPT, i18n.CodeInvalidUserID))
+	assert.Equal(t, "Role code is required", bundle.Text(i18n.LocaleEn, i18n.MessageRoleCodeRequired))
+	assert.Equal(t, "unknown_code", bundle.Message(i18n.LocaleEn, i18n.Code("unknown_code")))
+}
+
+// TestMiddleware verifica a propagação do idioma no contexto e no cabeçalho de resposta
+func TestMiddleware(t *testing.T) {
+	var locale string
+	handler := i18n.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = i18n.FromRequest(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/roles", nil)
+	req.Header.Set("Accept-Language", "es-MX")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, i18n.LocaleEs, locale)
+	assert.Equal(t, i18n.LocaleEs, rr.Header().Get("Content-Language"))
+}
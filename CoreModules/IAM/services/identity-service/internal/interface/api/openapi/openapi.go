@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"innovabiz/iam/identity-service/internal/interface/api/handler"
+)
+
+// Metadados do documento gerado
+const (
+	Title      = "INNOVABIZ IAM - Identity Service"
+	APIVersion = "1.0.0"
+)
+
+// Componentes reutilizáveis partilhados por todas as operações
+const (
+	errorResponseName       = "Error"
+	tenantHeaderName        = "TenantID"
+	userHeaderName          = "UserID"
+	languageHeaderName      = "AcceptLanguage"
+	jsonContentType         = "application/json"
+	formContentType         = "application/x-www-form-urlencoded"
+	samlMetadataContentType = "application/samlmetadata+xml"
+	octetStreamContentType  = "application/octet-stream"
+	csvContentType          = "text/csv"
+	ndjsonContentType       = "application/x-ndjson"
+	pageSchemaSuffix        = "Page"
+	parameterRefPrefix      = "#/components/parameters/"
+	responseRefPrefix       = "#/components/responses/"
+)
+
+// pathParamPattern identifica os segmentos {nome} dos caminhos
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build gera o documento OpenAPI a partir das rotas e dos tipos dos handlers
+func Build() *Document {
+	registry := NewRegistry()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       Title,
+			Version:     APIVersion,
+			Description: "API REST de gestão de funções, permissões e atribuições de usuários",
+		},
+		Servers: []Server{{URL: "/"}},
+		Tags: []Tag{
+			{Name: TagRoles, Description: "Gestão de funções"},
+			{Name: TagPermissions, Description: "Permissões atribuídas a funções"},
+			{Name: TagHierarchy, Description: "Hierarquia de funções"},
+			{Name: TagUsers, Description: "Atribuição de usuários a funções"},
+			{Name: TagHealth, Description: "Verificações de saúde do serviço"},
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Parameters: map[string]*Parameter{
+				tenantHeaderName: {Name: "X-Tenant-ID", In: "header", Description: "Tenant da requisição",
+					Schema: &Schema{Type: "string", Format: "uuid"}},
+				userHeaderName: {Name: "X-User-ID", In: "header", Description: "Usuário autor da operação",
+					Schema: &Schema{Type: "string", Format: "uuid"}},
+				languageHeaderName: {Name: "Accept-Language", In: "header", Description: "Idioma das mensagens de erro",
+					Schema: &Schema{Type: "string"}},
+			},
+			Responses: map[string]*Response{
+				errorResponseName: {
+					Description: "Erro padronizado com código estável e mensagem traduzida",
+					Content:     jsonContent(registry.SchemaFor(handler.ErrorResponse{})),
+				},
+			},
+		},
+	}
+
+	for _, route := range Routes() {
+		route.Path = BasePath + route.Path
+		doc.addRoute(registry, route, true)
+	}
+	for _, route := range HealthRoutes() {
+		doc.addRoute(registry, route, false)
+	}
+
+	doc.Components.Schemas = registry.Schemas()
+	return doc
+}
+
+// addRoute adiciona a operação de uma rota ao documento
+func (d *Document) addRoute(registry *Registry, route Route, withHeaders bool) {
+	op := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Tags:        []string{route.Tag},
+		Responses:   make(map[string]*Response),
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		})
+	}
+	for _, q := range route.Query {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        q.Name,
+			In:          "query",
+			Description: q.Description,
+			Schema:      &Schema{Type: q.Type},
+		})
+	}
+	if withHeaders {
+		op.Parameters = append(op.Parameters,
+			&Parameter{Ref: parameterRefPrefix + tenantHeaderName},
+			&Parameter{Ref: parameterRefPrefix + userHeaderName},
+			&Parameter{Ref: parameterRefPrefix + languageHeaderName},
+		)
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  mediaContent(route.RequestType, registry.SchemaFor(route.Request)),
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		schema := registry.SchemaFor(route.Response)
+		if route.Paginated {
+			schema = pageSchema(registry, schema)
+		}
+		if route.Alternative != nil {
+			schema = &Schema{OneOf: []*Schema{schema, registry.SchemaFor(route.Alternative)}}
+		}
+		success.Content = mediaContent(route.ResponseType, schema)
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	if withHeaders {
+		op.Responses["default"] = &Response{Ref: responseRefPrefix + errorResponseName}
+	}
+
+	item, ok := d.Paths[route.Path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[route.Path] = item
+	}
+	item.setOperation(route.Method, op)
+}
+
+// pageSchema regista o envelope paginado de um item e retorna a sua referência
+func pageSchema(registry *Registry, item *Schema) *Schema {
+	name := RefName(item) + pageSchemaSuffix
+	return registry.Register(name, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":       {Type: "array", Items: item},
+			"pagination": registry.SchemaFor(handler.PaginationResponse{}),
+		},
+		Required: []string{"data"},
+	})
+}
+
+// jsonContent associa um schema ao tipo de conteúdo JSON
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{jsonContentType: {Schema: schema}}
+}
+
+// mediaContent associa um schema ao tipo de conteúdo indicado, JSON por omissão
+func mediaContent(contentType string, schema *Schema) map[string]MediaType {
+	if contentType == "" {
+		return jsonContent(schema)
+	}
+	return map[string]MediaType{contentType: {Schema: schema}}
+}
+
+// Methods lista os métodos HTTP suportados por PathItem, na ordem de geração
+var Methods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch}
+
+// Operations percorre as operações do documento por caminho e método
+func (d *Document) Operations(fn func(path, method string, op *Operation)) {
+	for path, item := range d.Paths {
+		for _, method := range Methods {
+			if op := item.Operation(method); op != nil {
+				fn(path, method, op)
+			}
+		}
+	}
+}
+
+// Handler serve o documento OpenAPI em JSON, gerado uma única vez
+func Handler() http.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			body, err = json.Marshal(Build())
+		})
+		if err != nil {
+			http.Error(w, "falha ao gerar documento OpenAPI", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", jsonContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	})
+}
@@ -0,0 +1,766 @@
+package openapi
+
+import (
+	"net/http"
+
+	"innovabiz/iam/identity-service/internal/application"
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/api/handler"
+)
+
+// BasePath é o prefixo das rotas REST registadas pelo servidor
+const BasePath = "/api/v1"
+
+// Grupos de operações do documento
+const (
+	TagRoles                = "roles"
+	TagPermissions          = "permissions"
+	TagHierarchy            = "hierarchy"
+	TagUsers                = "users"
+	TagAccessRequests       = "access-requests"
+	TagRoleTemplates        = "role-templates"
+	TagSAMLFederation       = "saml-federation"
+	TagSecurityIncidents    = "security-incidents"
+	TagTenantExports        = "tenant-exports"
+	TagPermissionDecisions  = "permission-decisions"
+	TagRoleSuggestions      = "role-suggestions"
+	TagPasswordless         = "passwordless"
+	TagNetworkPolicies      = "network-policies"
+	TagSessionPolicy        = "session-policy"
+	TagRoleMetadata         = "role-metadata-policies"
+	TagEmergencyAccess      = "emergency-access"
+	TagAccountLinks         = "account-links"
+	TagLegalConsents        = "legal-consents"
+	TagTokenExchange        = "token-exchange"
+	TagAuditLogs            = "audit-logs"
+	TagServiceAccounts      = "service-accounts"
+	TagRecertification      = "recertification"
+	TagUserAttributes       = "user-attributes"
+	TagLoginNotifications   = "login-notifications"
+	TagPermissionBoundaries = "permission-boundaries"
+	TagBulkUserJobs         = "bulk-user-jobs"
+	TagTenantBranding       = "tenant-branding"
+	TagDevices              = "devices"
+	TagHealth               = "health"
+)
+
+// Route descreve uma rota REST servida pela API
+// Os parâmetros de caminho são derivados dos segmentos {nome} do caminho
+type Route struct {
+	Method       string
+	Path         string
+	OperationID  string
+	Tag          string
+	Summary      string
+	Query        []QueryParam
+	Request      interface{}
+	Response     interface{}
+	Alternative  interface{} // Forma alternativa da resposta, selecionada por parâmetro de query
+	Status       int
+	RequestType  string // Tipo de conteúdo do corpo quando não é JSON
+	ResponseType string // Tipo de conteúdo da resposta quando não é JSON
+	Paginated    bool
+}
+
+// QueryParam descreve um parâmetro de query de uma rota
+type QueryParam struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// healthResponse representa a resposta das verificações de saúde
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// Parâmetros de query partilhados entre rotas
+var (
+	pageParams = []QueryParam{
+		{Name: "page", Type: "integer", Description: "Página a retornar (começa em 1)"},
+		{Name: "pageSize", Type: "integer", Description: "Itens por página (máximo 100)"},
+	}
+	includeExpiredParam = QueryParam{Name: "includeExpired", Type: "boolean", Description: "Inclui atribuições expiradas"}
+	directOnlyParam     = QueryParam{Name: "directOnly", Type: "boolean", Description: "Considera apenas atribuições diretas"}
+	includeDepthParam   = QueryParam{Name: "includeDepth", Type: "boolean", Description: "Retorna a profundidade de cada função na hierarquia"}
+)
+
+// auditLogParams retorna os filtros da consulta dos registos de auditoria seguidos dos parâmetros indicados
+func auditLogParams(extra ...QueryParam) []QueryParam {
+	params := []QueryParam{
+		{Name: "q", Type: "string", Description: "Texto livre pesquisado nos detalhes, no tipo de evento e no tipo de alvo"},
+		{Name: "actor_id", Type: "string", Description: "Usuário que executou a ação"},
+		{Name: "target_type", Type: "string", Description: "Tipo da entidade afetada"},
+		{Name: "target_id", Type: "string", Description: "Identificador da entidade afetada; requer target_type"},
+		{Name: "event_type", Type: "string", Description: "Tipos de evento, separados por vírgulas"},
+		{Name: "severity", Type: "string", Description: "Severidades (info, low, medium, high, critical), separadas por vírgulas"},
+		{Name: "market", Type: "string", Description: "Mercado do registo"},
+		{Name: "from", Type: "string", Description: "Instante inicial (RFC 3339), inclusivo"},
+		{Name: "to", Type: "string", Description: "Instante final (RFC 3339), exclusivo"},
+	}
+	return append(params, extra...)
+}
+
+// Routes retorna as rotas REST registadas pelo servidor
+// Deve acompanhar RoleHandler.RegisterRoutes e as rotas de saúde do servidor
+func Routes() []Route {
+	return []Route{
+		// Operações CRUD básicas
+		{Method: http.MethodPost, Path: "/roles", OperationID: "createRole", Tag: TagRoles,
+			Summary: "Cria uma função", Request: handler.RoleRequest{}, Response: handler.RoleResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/roles/{id}", OperationID: "getRole", Tag: TagRoles,
+			Summary: "Obtém uma função", Response: handler.RoleResponse{}},
+		{Method: http.MethodGet, Path: "/roles", OperationID: "listRoles", Tag: TagRoles,
+			Summary: "Lista funções com filtros e paginação",
+			Query: append([]QueryParam{
+				{Name: "code", Type: "string", Description: "Filtra pelo código"},
+				{Name: "name", Type: "string", Description: "Filtra pelo nome"},
+				{Name: "type", Type: "string", Description: "Filtra pelo tipo"},
+				{Name: "isActive", Type: "boolean", Description: "Filtra por funções ativas"},
+				{Name: "isSystem", Type: "boolean", Description: "Filtra por funções de sistema"},
+			}, pageParams...),
+			Response: handler.RoleResponse{}, Paginated: true},
+		{Method: http.MethodPut, Path: "/roles/{id}", OperationID: "updateRole", Tag: TagRoles,
+			Summary: "Atualiza uma função", Request: handler.RoleRequest{}, Response: handler.RoleResponse{}},
+		{Method: http.MethodDelete, Path: "/roles/{id}", OperationID: "deleteRole", Tag: TagRoles,
+			Summary: "Remove uma função",
+			Query:   []QueryParam{{Name: "permanent", Type: "boolean", Description: "Remove definitivamente em vez de desativar"}},
+			Status:  http.StatusNoContent},
+
+		// Operações de permissões
+		{Method: http.MethodGet, Path: "/roles/{id}/permissions", OperationID: "getRolePermissions", Tag: TagPermissions,
+			Summary: "Lista as permissões diretas de uma função", Query: pageParams,
+			Response: handler.PermissionResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/roles/{id}/permissions/all", OperationID: "getAllRolePermissions", Tag: TagPermissions,
+			Summary: "Lista as permissões diretas e herdadas de uma função", Response: []handler.PermissionResponse{}},
+		{Method: http.MethodPost, Path: "/roles/{roleId}/permissions/{permissionId}", OperationID: "assignPermission", Tag: TagPermissions,
+			Summary: "Atribui uma permissão a uma função", Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/roles/{roleId}/permissions/{permissionId}", OperationID: "revokePermission", Tag: TagPermissions,
+			Summary: "Revoga uma permissão de uma função", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/roles/{roleId}/permissions/{permissionId}/check", OperationID: "checkPermission", Tag: TagPermissions,
+			Summary: "Verifica se uma função possui uma permissão", Query: []QueryParam{directOnlyParam},
+			Response: handler.PermissionCheckResponse{}},
+
+		// Operações de hierarquia
+		{Method: http.MethodGet, Path: "/roles/{id}/children", OperationID: "getChildRoles", Tag: TagHierarchy,
+			Summary: "Lista as funções filhas diretas", Query: pageParams, Response: handler.RoleResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/roles/{id}/parents", OperationID: "getParentRoles", Tag: TagHierarchy,
+			Summary: "Lista as funções pais diretas", Query: pageParams, Response: handler.RoleResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/roles/{id}/descendants", OperationID: "getDescendantRoles", Tag: TagHierarchy,
+			Summary: "Lista todas as funções descendentes", Query: []QueryParam{includeDepthParam},
+			Response: []handler.RoleResponse{}, Alternative: []handler.RoleWithDepthResponse{}},
+		{Method: http.MethodGet, Path: "/roles/{id}/ancestors", OperationID: "getAncestorRoles", Tag: TagHierarchy,
+			Summary: "Lista todas as funções ancestrais", Query: []QueryParam{includeDepthParam},
+			Response: []handler.RoleResponse{}, Alternative: []handler.RoleWithDepthResponse{}},
+		{Method: http.MethodPost, Path: "/roles/{parentId}/children/{childId}", OperationID: "assignChildRole", Tag: TagHierarchy,
+			Summary: "Atribui uma função filha", Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/roles/{parentId}/children/{childId}", OperationID: "removeChildRole", Tag: TagHierarchy,
+			Summary: "Remove uma função filha", Status: http.StatusNoContent},
+
+		// Operações de usuários
+		{Method: http.MethodGet, Path: "/roles/{id}/users", OperationID: "getRoleUsers", Tag: TagUsers,
+			Summary: "Lista os usuários atribuídos a uma função", Query: append([]QueryParam{includeExpiredParam}, pageParams...),
+			Response: handler.UserRoleResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/users/{userId}/roles", OperationID: "getUserRoles", Tag: TagUsers,
+			Summary: "Lista as funções atribuídas diretamente a um usuário", Query: append([]QueryParam{includeExpiredParam}, pageParams...),
+			Response: handler.RoleUserResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/users/{userId}/roles/all", OperationID: "getAllUserRoles", Tag: TagUsers,
+			Summary: "Lista as funções diretas e herdadas de um usuário", Query: []QueryParam{includeExpiredParam},
+			Response: []handler.RoleResponse{}},
+		{Method: http.MethodPost, Path: "/roles/{roleId}/users/{userId}", OperationID: "assignUserToRole", Tag: TagUsers,
+			Summary: "Atribui um usuário a uma função", Request: handler.UserRoleAssignmentRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPut, Path: "/roles/{roleId}/users/{userId}", OperationID: "updateUserRoleExpiration", Tag: TagUsers,
+			Summary: "Atualiza a expiração da atribuição de um usuário", Request: handler.UserRoleAssignmentRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/roles/{roleId}/users/{userId}", OperationID: "removeUserFromRole", Tag: TagUsers,
+			Summary: "Remove um usuário de uma função", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/roles/{roleId}/users/{userId}/check", OperationID: "checkUserInRole", Tag: TagUsers,
+			Summary: "Verifica se um usuário pertence a uma função", Query: []QueryParam{directOnlyParam, includeExpiredParam},
+			Response: handler.RoleMembershipResponse{}},
+
+		// Operações especiais
+		{Method: http.MethodPost, Path: "/roles/{id}/clone", OperationID: "cloneRole", Tag: TagRoles,
+			Summary: "Clona uma função", Request: handler.CloneRoleRequest{}, Response: handler.RoleResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/system-roles/sync", OperationID: "syncSystemRoles", Tag: TagRoles,
+			Summary: "Sincroniza as funções de sistema", Response: []handler.RoleResponse{}},
+
+		// Análise de impacto de alterações de acesso
+		{Method: http.MethodPost, Path: "/roles/{id}/impact-analysis", OperationID: "analyzeImpact", Tag: TagRoles,
+			Summary: "Calcula o impacto de uma alteração proposta sem aplicá-la",
+			Request: handler.ImpactAnalysisRequest{}, Response: application.ImpactReport{}},
+
+		// Histórico imutável de funções
+		{Method: http.MethodGet, Path: "/roles/{id}/history", OperationID: "getRoleHistory", Tag: TagRoles,
+			Summary: "Lista os eventos do histórico de uma função", Response: []model.RoleHistoryEvent{}},
+		{Method: http.MethodGet, Path: "/roles/{id}/history/at", OperationID: "getRoleAt", Tag: TagRoles,
+			Summary:  "Reconstrói o estado de uma função num instante",
+			Query:    []QueryParam{{Name: "timestamp", Type: "string", Description: "Instante a reconstruir (RFC 3339)"}},
+			Response: model.RoleState{}},
+		{Method: http.MethodGet, Path: "/roles/{id}/history/diff", OperationID: "diffRole", Tag: TagRoles,
+			Summary: "Compara o estado de uma função entre dois instantes",
+			Query: []QueryParam{
+				{Name: "from", Type: "string", Description: "Instante inicial (RFC 3339)"},
+				{Name: "to", Type: "string", Description: "Instante final (RFC 3339)"},
+			},
+			Response: model.RoleStateDiff{}},
+
+		// Pedidos de acesso em autoatendimento
+		{Method: http.MethodPost, Path: "/access-requests", OperationID: "submitAccessRequest", Tag: TagAccessRequests,
+			Summary: "Pede uma função ou permissão para o usuário autenticado",
+			Request: handler.AccessRequestCreateRequest{}, Response: model.AccessRequest{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/access-requests", OperationID: "listAccessRequests", Tag: TagAccessRequests,
+			Summary:  "Lista os pedidos do usuário ou os pendentes que ele pode decidir",
+			Query:    []QueryParam{{Name: "view", Type: "string", Description: "mine (padrão) ou pending"}},
+			Response: []model.AccessRequest{}},
+		{Method: http.MethodGet, Path: "/access-requests/sla", OperationID: "getAccessRequestSLA", Tag: TagAccessRequests,
+			Summary: "Obtém as métricas de prazo de decisão dos pedidos do tenant", Response: application.AccessRequestSLAMetrics{}},
+		{Method: http.MethodGet, Path: "/access-requests/{id}", OperationID: "getAccessRequest", Tag: TagAccessRequests,
+			Summary: "Obtém um pedido de acesso", Response: model.AccessRequest{}},
+		{Method: http.MethodPost, Path: "/access-requests/{id}/approve", OperationID: "approveAccessRequest", Tag: TagAccessRequests,
+			Summary: "Aprova um pedido e concede o acesso", Request: handler.AccessRequestDecisionRequest{}, Response: model.AccessRequest{}},
+		{Method: http.MethodPost, Path: "/access-requests/{id}/reject", OperationID: "rejectAccessRequest", Tag: TagAccessRequests,
+			Summary: "Rejeita um pedido de acesso", Request: handler.AccessRequestDecisionRequest{}, Response: model.AccessRequest{}},
+		{Method: http.MethodPost, Path: "/access-requests/{id}/cancel", OperationID: "cancelAccessRequest", Tag: TagAccessRequests,
+			Summary: "Retira um pedido pendente do usuário autenticado", Response: model.AccessRequest{}},
+		{Method: http.MethodGet, Path: "/access-approvers", OperationID: "listAccessApprovers", Tag: TagAccessRequests,
+			Summary: "Lista os aprovadores de pedidos de acesso", Response: []model.AccessRequestApprover{}},
+		{Method: http.MethodPost, Path: "/access-approvers", OperationID: "addAccessApprover", Tag: TagAccessRequests,
+			Summary: "Atribui autoridade de aprovação a um usuário",
+			Request: handler.AccessApproverRequest{}, Response: model.AccessRequestApprover{}, Status: http.StatusCreated},
+		{Method: http.MethodDelete, Path: "/access-approvers/{id}", OperationID: "removeAccessApprover", Tag: TagAccessRequests,
+			Summary: "Retira a autoridade de um aprovador", Status: http.StatusNoContent},
+
+		// Biblioteca de modelos de função partilhada entre tenants
+		{Method: http.MethodPost, Path: "/role-templates", OperationID: "createRoleTemplate", Tag: TagRoleTemplates,
+			Summary: "Publica um modelo de função no catálogo global",
+			Request: handler.RoleTemplateCreateRequest{}, Response: model.RoleTemplate{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/role-templates", OperationID: "listRoleTemplates", Tag: TagRoleTemplates,
+			Summary: "Lista o catálogo de modelos de função",
+			Query: []QueryParam{
+				{Name: "market", Type: "string", Description: "Restringe aos modelos do mercado e aos disponíveis para todos os mercados"},
+				{Name: "includeInactive", Type: "boolean", Description: "Inclui os modelos inativos"},
+			},
+			Response: []model.RoleTemplate{}},
+		{Method: http.MethodGet, Path: "/role-templates/instances", OperationID: "listRoleTemplateInstances", Tag: TagRoleTemplates,
+			Summary: "Lista as funções do tenant instanciadas a partir de modelos", Response: []model.RoleTemplateInstance{}},
+		{Method: http.MethodGet, Path: "/role-templates/drift", OperationID: "detectTenantRoleTemplateDrift", Tag: TagRoleTemplates,
+			Summary:  "Compara as funções instanciadas do tenant com os seus modelos",
+			Query:    []QueryParam{{Name: "driftedOnly", Type: "boolean", Description: "Retorna apenas as funções com desvio"}},
+			Response: []model.RoleTemplateDrift{}},
+		{Method: http.MethodGet, Path: "/role-templates/{id}", OperationID: "getRoleTemplate", Tag: TagRoleTemplates,
+			Summary: "Obtém um modelo de função", Response: model.RoleTemplate{}},
+		{Method: http.MethodPut, Path: "/role-templates/{id}", OperationID: "updateRoleTemplate", Tag: TagRoleTemplates,
+			Summary: "Revê um modelo de função; a alteração das permissões avança a versão",
+			Request: handler.RoleTemplateUpdateRequest{}, Response: model.RoleTemplate{}},
+		{Method: http.MethodPost, Path: "/role-templates/{id}/instantiate", OperationID: "instantiateRoleTemplate", Tag: TagRoleTemplates,
+			Summary: "Cria no tenant uma função a partir do modelo",
+			Request: handler.RoleTemplateInstantiateRequest{}, Response: application.RoleTemplateInstantiation{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/roles/{id}/template-drift", OperationID: "getRoleTemplateDrift", Tag: TagRoleTemplates,
+			Summary: "Compara uma função instanciada com a versão atual do seu modelo", Response: model.RoleTemplateDrift{}},
+
+		// Federação com fornecedores de identidade SAML 2.0
+		{Method: http.MethodPost, Path: "/saml-providers", OperationID: "registerSAMLProvider", Tag: TagSAMLFederation,
+			Summary: "Regista um fornecedor de identidade SAML no tenant",
+			Request: handler.SAMLProviderRequest{}, Response: model.SAMLIdentityProvider{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/saml-providers", OperationID: "listSAMLProviders", Tag: TagSAMLFederation,
+			Summary: "Lista os fornecedores de identidade SAML do tenant", Response: []model.SAMLIdentityProvider{}},
+		{Method: http.MethodGet, Path: "/saml-providers/{id}", OperationID: "getSAMLProvider", Tag: TagSAMLFederation,
+			Summary: "Obtém um fornecedor de identidade SAML", Response: model.SAMLIdentityProvider{}},
+		{Method: http.MethodPut, Path: "/saml-providers/{id}", OperationID: "updateSAMLProvider", Tag: TagSAMLFederation,
+			Summary: "Altera um fornecedor de identidade SAML",
+			Request: handler.SAMLProviderUpdateRequest{}, Response: model.SAMLIdentityProvider{}},
+		{Method: http.MethodGet, Path: "/saml/{id}/metadata", OperationID: "getSAMLServiceProviderMetadata", Tag: TagSAMLFederation,
+			Summary:  "Devolve os metadados do fornecedor de serviço a registar no IdP",
+			Response: "", ResponseType: samlMetadataContentType},
+		{Method: http.MethodGet, Path: "/saml/{id}/login", OperationID: "startSAMLLogin", Tag: TagSAMLFederation,
+			Summary: "Redireciona o navegador para o IdP com um pedido de autenticação",
+			Query:   []QueryParam{{Name: "returnTo", Type: "string", Description: "Caminho relativo a apresentar após o login"}},
+			Status:  http.StatusFound},
+		{Method: http.MethodPost, Path: "/saml/{id}/acs", OperationID: "consumeSAMLAssertion", Tag: TagSAMLFederation,
+			Summary: "Recebe a resposta do IdP e conclui o login federado",
+			Request: handler.SAMLAssertionForm{}, RequestType: formContentType, Response: application.SAMLLoginResult{}},
+
+		// Triagem de incidentes de segurança detetados no fluxo de auditoria
+		{Method: http.MethodGet, Path: "/security-incidents", OperationID: "listSecurityIncidents", Tag: TagSecurityIncidents,
+			Summary: "Lista os incidentes de segurança do tenant, do mais recente para o mais antigo",
+			Query: []QueryParam{
+				{Name: "status", Type: "string", Description: "open, acknowledged, resolved ou dismissed"},
+				{Name: "severity", Type: "string", Description: "low, medium, high ou critical"},
+				{Name: "rule", Type: "string", Description: "Regra de deteção que originou o incidente"},
+				{Name: "since", Type: "string", Description: "Instante inicial da deteção (RFC 3339)"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de incidentes (máximo 100)"},
+			},
+			Response: []model.SecurityIncident{}},
+		{Method: http.MethodGet, Path: "/security-incidents/{id}", OperationID: "getSecurityIncident", Tag: TagSecurityIncidents,
+			Summary: "Obtém um incidente de segurança com as evidências recolhidas", Response: model.SecurityIncident{}},
+		{Method: http.MethodPost, Path: "/security-incidents/{id}/acknowledge", OperationID: "acknowledgeSecurityIncident", Tag: TagSecurityIncidents,
+			Summary: "Assinala que o incidente está a ser analisado",
+			Request: handler.SecurityIncidentTriageRequest{}, Response: model.SecurityIncident{}},
+		{Method: http.MethodPost, Path: "/security-incidents/{id}/resolve", OperationID: "resolveSecurityIncident", Tag: TagSecurityIncidents,
+			Summary: "Fecha o incidente após a correção",
+			Request: handler.SecurityIncidentTriageRequest{}, Response: model.SecurityIncident{}},
+		{Method: http.MethodPost, Path: "/security-incidents/{id}/dismiss", OperationID: "dismissSecurityIncident", Tag: TagSecurityIncidents,
+			Summary: "Fecha o incidente como falso positivo",
+			Request: handler.SecurityIncidentTriageRequest{}, Response: model.SecurityIncident{}},
+
+		// Exportação dos dados do tenant na saída da plataforma
+		{Method: http.MethodPost, Path: "/tenant-exports", OperationID: "startTenantExport", Tag: TagTenantExports,
+			Summary: "Regista a exportação dos dados IAM do tenant; a geração é assíncrona",
+			Request: handler.TenantExportRequest{}, Response: model.TenantExport{}, Status: http.StatusAccepted},
+		{Method: http.MethodGet, Path: "/tenant-exports", OperationID: "listTenantExports", Tag: TagTenantExports,
+			Summary: "Lista as exportações do tenant, da mais recente para a mais antiga", Response: []model.TenantExport{}},
+		{Method: http.MethodGet, Path: "/tenant-exports/signing-key", OperationID: "getTenantExportSigningKey", Tag: TagTenantExports,
+			Summary: "Obtém a chave pública que verifica os manifestos", Response: application.TenantExportSigningKey{}},
+		{Method: http.MethodGet, Path: "/tenant-exports/{id}", OperationID: "getTenantExport", Tag: TagTenantExports,
+			Summary: "Obtém o estado e o progresso por secção de uma exportação", Response: model.TenantExport{}},
+		{Method: http.MethodPost, Path: "/tenant-exports/{id}/resume", OperationID: "resumeTenantExport", Tag: TagTenantExports,
+			Summary:  "Retoma uma exportação falhada a partir do último lote gravado",
+			Response: model.TenantExport{}, Status: http.StatusAccepted},
+		{Method: http.MethodGet, Path: "/tenant-exports/{id}/files/{name}", OperationID: "downloadTenantExportFile", Tag: TagTenantExports,
+			Summary:  "Descarrega um ficheiro de dados ou o manifesto de uma exportação concluída",
+			Response: "", ResponseType: octetStreamContentType},
+
+		// Auditoria das decisões de autorização
+		{Method: http.MethodGet, Path: "/permission-decisions", OperationID: "listPermissionDecisions", Tag: TagPermissionDecisions,
+			Summary: "Consulta as decisões de autorização do tenant; as negações são todas registadas e as permissões amostradas",
+			Query: []QueryParam{
+				{Name: "user_id", Type: "string", Description: "Usuário a quem a decisão se aplica"},
+				{Name: "resource", Type: "string", Description: "Recurso exato ou prefixo terminado em *"},
+				{Name: "allowed", Type: "boolean", Description: "true para permissões, false para negações"},
+				{Name: "from", Type: "string", Description: "Instante inicial (RFC 3339), inclusivo"},
+				{Name: "to", Type: "string", Description: "Instante final (RFC 3339), exclusivo"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de decisões (máximo 1000)"},
+			},
+			Response: []model.PermissionDecision{}},
+
+		// Consulta dos registos de auditoria
+		{Method: http.MethodGet, Path: "/audit-logs", OperationID: "searchAuditLogs", Tag: TagAuditLogs,
+			Summary: "Consulta os registos de auditoria do tenant, do mais recente para o mais antigo, com paginação por cursor",
+			Query: auditLogParams(
+				QueryParam{Name: "cursor", Type: "string", Description: "Cursor next_cursor da página anterior"},
+				QueryParam{Name: "limit", Type: "integer", Description: "Número máximo de registos (padrão 100, máximo 1000)"},
+			),
+			Response: model.AuditLogPage{}},
+		{Method: http.MethodGet, Path: "/audit-logs/export", OperationID: "exportAuditLogs", Tag: TagAuditLogs,
+			Summary: "Descarrega em CSV os registos de auditoria do tenant; ao atingir o limite de linhas, " +
+				"o trailer X-Audit-Log-Next-Cursor indica o cursor para continuar",
+			Query: auditLogParams(
+				QueryParam{Name: "cursor", Type: "string", Description: "Cursor a partir do qual a exportação continua"},
+			),
+			Response: "", ResponseType: csvContentType},
+
+		// Sugestões de função mineradas das permissões diretas
+		{Method: http.MethodGet, Path: "/role-suggestions", OperationID: "listRoleSuggestions", Tag: TagRoleSuggestions,
+			Summary: "Lista as sugestões de função do tenant, das que substituem mais atribuições diretas para as que substituem menos",
+			Query: []QueryParam{
+				{Name: "status", Type: "string", Description: "Estado da sugestão (pending, accepted ou dismissed)"},
+			},
+			Response: []model.RoleSuggestion{}},
+		{Method: http.MethodPost, Path: "/role-suggestions/mine", OperationID: "mineRoleSuggestions", Tag: TagRoleSuggestions,
+			Summary: "Recalcula de imediato as sugestões pendentes do tenant", Response: []model.RoleSuggestion{}},
+		{Method: http.MethodGet, Path: "/role-suggestions/{id}", OperationID: "getRoleSuggestion", Tag: TagRoleSuggestions,
+			Summary: "Obtém uma sugestão de função com as permissões e os usuários abrangidos", Response: model.RoleSuggestion{}},
+		{Method: http.MethodPost, Path: "/role-suggestions/{id}/accept", OperationID: "acceptRoleSuggestion", Tag: TagRoleSuggestions,
+			Summary: "Cria a função sugerida e marca a sugestão como aceite",
+			Request: handler.AcceptRoleSuggestionRequest{}, Response: application.RoleSuggestionAcceptance{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/role-suggestions/{id}/dismiss", OperationID: "dismissRoleSuggestion", Tag: TagRoleSuggestions,
+			Summary: "Descarta a sugestão; o mesmo conjunto de permissões não volta a ser sugerido", Response: model.RoleSuggestion{}},
+
+		// Autenticação sem senha por link mágico e por código enviado por email
+		{Method: http.MethodGet, Path: "/passwordless/settings", OperationID: "getPasswordlessSettings", Tag: TagPasswordless,
+			Summary: "Obtém a configuração sem senha do tenant", Response: model.PasswordlessSettings{}},
+		{Method: http.MethodPut, Path: "/passwordless/settings", OperationID: "updatePasswordlessSettings", Tag: TagPasswordless,
+			Summary: "Altera a configuração sem senha do tenant, respeitando a política do mercado para tenants financeiros",
+			Request: handler.PasswordlessSettingsRequest{}, Response: model.PasswordlessSettings{}},
+		{Method: http.MethodPost, Path: "/passwordless/start", OperationID: "startPasswordlessLogin", Tag: TagPasswordless,
+			Summary: "Envia um link mágico ou um código de uso único para o email indicado",
+			Request: handler.PasswordlessStartRequest{}, Response: application.PasswordlessChallengeIssued{}, Status: http.StatusAccepted},
+		{Method: http.MethodPost, Path: "/passwordless/magic-link/verify", OperationID: "verifyPasswordlessMagicLink", Tag: TagPasswordless,
+			Summary: "Conclui o login com o token do link mágico",
+			Request: handler.PasswordlessMagicLinkRequest{}, Response: application.PasswordlessLoginResult{}},
+		{Method: http.MethodPost, Path: "/passwordless/otp/verify", OperationID: "verifyPasswordlessOTP", Tag: TagPasswordless,
+			Summary: "Conclui o login com o código enviado por email",
+			Request: handler.PasswordlessOTPRequest{}, Response: application.PasswordlessLoginResult{}},
+
+		// Políticas de rede por tenant e acessos de emergência
+		{Method: http.MethodGet, Path: "/network-policies", OperationID: "listNetworkPolicies", Tag: TagNetworkPolicies,
+			Summary: "Lista as políticas de rede do tenant", Response: []model.NetworkPolicy{}},
+		{Method: http.MethodPost, Path: "/network-policies", OperationID: "createNetworkPolicy", Tag: TagNetworkPolicies,
+			Summary: "Cria uma lista de endereços permitidos ou bloqueados para a API de administração ou para uma função",
+			Request: handler.NetworkPolicyRequest{}, Response: model.NetworkPolicy{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/network-policies/break-glass", OperationID: "listBreakGlassOverrides", Tag: TagNetworkPolicies,
+			Summary:  "Lista os acessos de emergência do tenant",
+			Query:    []QueryParam{{Name: "active", Type: "boolean", Description: "Retorna apenas os acessos em vigor"}},
+			Response: []model.BreakGlassOverride{}},
+		{Method: http.MethodPost, Path: "/network-policies/break-glass", OperationID: "activateBreakGlass", Tag: TagNetworkPolicies,
+			Summary: "Ativa um acesso de emergência temporário e auditado que ignora as políticas de rede",
+			Request: handler.BreakGlassRequest{}, Response: model.BreakGlassOverride{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/network-policies/break-glass/{id}/revoke", OperationID: "revokeBreakGlass", Tag: TagNetworkPolicies,
+			Summary: "Revoga um acesso de emergência antes de expirar", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/network-policies/break-glass/{id}/uses", OperationID: "listBreakGlassUses", Tag: TagNetworkPolicies,
+			Summary:  "Lista os pedidos permitidos por um acesso de emergência",
+			Query:    []QueryParam{{Name: "limit", Type: "integer", Description: "Número máximo de registos (máximo 1000)"}},
+			Response: []model.BreakGlassUse{}},
+		{Method: http.MethodGet, Path: "/network-policies/{id}", OperationID: "getNetworkPolicy", Tag: TagNetworkPolicies,
+			Summary: "Obtém uma política de rede do tenant", Response: model.NetworkPolicy{}},
+		{Method: http.MethodPut, Path: "/network-policies/{id}", OperationID: "updateNetworkPolicy", Tag: TagNetworkPolicies,
+			Summary: "Substitui uma política de rede do tenant", Request: handler.NetworkPolicyRequest{}, Response: model.NetworkPolicy{}},
+		{Method: http.MethodDelete, Path: "/network-policies/{id}", OperationID: "deleteNetworkPolicy", Tag: TagNetworkPolicies,
+			Summary: "Remove uma política de rede do tenant", Status: http.StatusNoContent},
+
+		// Validade dos tokens e duração das sessões por tenant
+		{Method: http.MethodGet, Path: "/session-policy", OperationID: "getSessionPolicy", Tag: TagSessionPolicy,
+			Summary: "Obtém a política de sessão do tenant ou os valores padrão do mercado", Response: model.SessionPolicy{}},
+		{Method: http.MethodPut, Path: "/session-policy", OperationID: "updateSessionPolicy", Tag: TagSessionPolicy,
+			Summary: "Altera a validade dos tokens e a duração das sessões, respeitando os limites do mercado para tenants financeiros",
+			Request: handler.SessionPolicyRequest{}, Response: model.SessionPolicy{}},
+		{Method: http.MethodDelete, Path: "/session-policy", OperationID: "resetSessionPolicy", Tag: TagSessionPolicy,
+			Summary: "Repõe a política de sessão nos valores padrão do mercado", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/session-policy/lifetimes", OperationID: "getSessionLifetimes", Tag: TagSessionPolicy,
+			Summary:  "Calcula a validade dos tokens a emitir para uma sessão",
+			Query:    []QueryParam{{Name: "auth_time", Type: "string", Description: "Momento da autenticação (RFC 3339); sem ele a sessão começa agora"}},
+			Response: model.SessionLifetimes{}},
+
+		// Políticas de acesso às chaves dos metadados das funções
+		{Method: http.MethodGet, Path: "/role-metadata-policies", OperationID: "listRoleMetadataPolicies", Tag: TagRoleMetadata,
+			Summary:  "Lista as chaves protegidas dos metadados das funções, definidas no catálogo de permissões, e o acesso do usuário",
+			Response: []handler.RoleMetadataPolicyResponse{}},
+
+		// Contas de emergência (break-glass)
+		{Method: http.MethodGet, Path: "/emergency-access/accounts", OperationID: "listEmergencyAccounts", Tag: TagEmergencyAccess,
+			Summary: "Lista as contas de emergência do tenant", Response: []model.EmergencyAccount{}},
+		{Method: http.MethodPost, Path: "/emergency-access/accounts", OperationID: "provisionEmergencyAccount", Tag: TagEmergencyAccess,
+			Summary: "Regista um usuário como conta de emergência, selada até uma ativação aprovada",
+			Request: handler.EmergencyAccountRequest{}, Response: model.EmergencyAccount{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/emergency-access/activations", OperationID: "listEmergencyActivations", Tag: TagEmergencyAccess,
+			Summary:  "Lista as ativações das contas de emergência",
+			Query:    []QueryParam{{Name: "status", Type: "string", Description: "Estado das ativações (expired e closed aguardam revisão)"}},
+			Response: []model.EmergencyActivation{}},
+		{Method: http.MethodPost, Path: "/emergency-access/activations", OperationID: "requestEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Pede a ativação de uma conta de emergência com referência ao incidente; fica pendente de aprovação",
+			Request: handler.EmergencyActivationRequest{}, Response: model.EmergencyActivation{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/emergency-access/activations/{id}", OperationID: "getEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Obtém uma ativação de conta de emergência", Response: model.EmergencyActivation{}},
+		{Method: http.MethodPost, Path: "/emergency-access/activations/{id}/approve", OperationID: "approveEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Aprova uma ativação; o aprovador tem de ser diferente do requerente", Response: model.EmergencyActivation{}},
+		{Method: http.MethodPost, Path: "/emergency-access/activations/{id}/reject", OperationID: "rejectEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Recusa um pedido de ativação pendente",
+			Request: handler.EmergencyActivationReasonRequest{}, Response: model.EmergencyActivation{}},
+		{Method: http.MethodPost, Path: "/emergency-access/activations/{id}/close", OperationID: "closeEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Termina uma ativação antes de expirar",
+			Request: handler.EmergencyActivationReasonRequest{}, Response: model.EmergencyActivation{}},
+		{Method: http.MethodPost, Path: "/emergency-access/activations/{id}/review", OperationID: "reviewEmergencyActivation", Tag: TagEmergencyAccess,
+			Summary: "Regista a revisão de uma ativação terminada por quem não a pediu nem aprovou",
+			Request: handler.EmergencyActivationReviewRequest{}, Response: model.EmergencyActivation{}},
+		{Method: http.MethodGet, Path: "/emergency-access/activations/{id}/actions", OperationID: "listEmergencyActions", Tag: TagEmergencyAccess,
+			Summary: "Lista as ações gravadas durante uma ativação, por ordem da sequência",
+			Query: []QueryParam{
+				{Name: "after", Type: "integer", Description: "Retorna as ações com sequência superior"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de registos (máximo 1000)"},
+			},
+			Response: []model.EmergencyAction{}},
+		{Method: http.MethodGet, Path: "/emergency-access/activations/{id}/actions/verify", OperationID: "verifyEmergencyActions", Tag: TagEmergencyAccess,
+			Summary: "Verifica a cadeia de hashes do registo das ações de uma ativação", Response: model.EmergencyChainVerification{}},
+
+		// Ligação de contas entre fornecedores de identidade
+		// A ligação e a remoção exigem o cabeçalho X-Auth-Time com uma autenticação recente
+		{Method: http.MethodGet, Path: "/linked-identities", OperationID: "listLinkedIdentities", Tag: TagAccountLinks,
+			Summary: "Lista os métodos de login do usuário autenticado: senha local e identidades federadas", Response: []model.LinkedIdentity{}},
+		{Method: http.MethodPost, Path: "/linked-identities/{id}/unlink", OperationID: "unlinkIdentity", Tag: TagAccountLinks,
+			Summary: "Remove uma identidade federada, mantendo pelo menos outro método de login", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/account-links/candidates", OperationID: "listAccountLinkCandidates", Tag: TagAccountLinks,
+			Summary:  "Lista os logins federados pendentes de ligação à conta do usuário autenticado",
+			Response: []model.AccountLinkCandidate{}},
+		{Method: http.MethodPost, Path: "/account-links/candidates/{id}/link", OperationID: "linkAccountCandidate", Tag: TagAccountLinks,
+			Summary:  "Liga o login federado à conta do usuário autenticado",
+			Response: model.LinkedIdentity{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/account-links/candidates/{id}/dismiss", OperationID: "dismissAccountLinkCandidate", Tag: TagAccountLinks,
+			Summary: "Recusa a ligação de um login federado", Response: model.AccountLinkCandidate{}},
+		{Method: http.MethodGet, Path: "/account-links/events", OperationID: "listAccountLinkEvents", Tag: TagAccountLinks,
+			Summary: "Lista o registo de auditoria da ligação de contas do tenant",
+			Query: []QueryParam{
+				{Name: "user_id", Type: "string", Description: "Usuário afetado"},
+				{Name: "action", Type: "string", Description: "Operação registada (candidate_detected, candidate_dismissed, linked, unlinked)"},
+				{Name: "since", Type: "string", Description: "Início do período (RFC 3339)"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de registos (máximo 1000)"},
+			},
+			Response: []model.AccountLinkEvent{}},
+
+		// Termos de serviço, políticas de privacidade e consentimentos versionados por tenant
+		// Os logins bloqueados por documentos obrigatórios respondem 403 com pending_documents
+		{Method: http.MethodGet, Path: "/legal-documents", OperationID: "listLegalDocuments", Tag: TagLegalConsents,
+			Summary:  "Lista as versões publicadas dos documentos legais do tenant",
+			Query:    []QueryParam{{Name: "current", Type: "boolean", Description: "Retorna apenas a versão em vigor de cada tipo"}},
+			Response: []model.LegalDocument{}},
+		{Method: http.MethodPost, Path: "/legal-documents", OperationID: "publishLegalDocument", Tag: TagLegalConsents,
+			Summary: "Publica uma nova versão de um documento legal, com o hash do conteúdo",
+			Request: handler.LegalDocumentRequest{}, Response: model.LegalDocument{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/legal-documents/pending", OperationID: "listPendingLegalDocuments", Tag: TagLegalConsents,
+			Summary: "Lista as versões em vigor que o usuário autenticado ainda não aceitou", Response: []model.LegalDocument{}},
+		{Method: http.MethodGet, Path: "/legal-documents/{id}", OperationID: "getLegalDocument", Tag: TagLegalConsents,
+			Summary: "Obtém uma versão de um documento legal", Response: model.LegalDocument{}},
+		{Method: http.MethodPost, Path: "/legal-documents/{id}/accept", OperationID: "acceptLegalDocument", Tag: TagLegalConsents,
+			Summary: "Regista a aceitação da versão em vigor pelo usuário autenticado",
+			Request: handler.LegalAcceptanceRequest{}, Response: model.LegalAcceptance{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/legal-documents/{id}/report", OperationID: "getLegalAcceptanceReport", Tag: TagLegalConsents,
+			Summary: "Resume a aceitação de uma versão pelos usuários ativos do tenant", Response: model.LegalAcceptanceReport{}},
+		{Method: http.MethodGet, Path: "/legal-acceptances", OperationID: "listLegalAcceptances", Tag: TagLegalConsents,
+			Summary: "Lista os registos de aceitação dos documentos legais do tenant",
+			Query: []QueryParam{
+				{Name: "user_id", Type: "string", Description: "Usuário que aceitou"},
+				{Name: "document_id", Type: "string", Description: "Versão do documento aceite"},
+				{Name: "since", Type: "string", Description: "Início do período (RFC 3339)"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de registos (máximo 1000)"},
+			},
+			Response: []model.LegalAcceptance{}},
+
+		// Troca de tokens para delegação entre serviços (RFC 8693)
+		// Os erros da troca seguem o formato do RFC 6749 (error e error_description)
+		{Method: http.MethodPost, Path: "/oauth/token-exchange", OperationID: "exchangeToken", Tag: TagTokenExchange,
+			Summary: "Troca o token do usuário e o do serviço que age por um token delegado de curta duração para uma audiência",
+			Request: handler.TokenExchangeRequest{}, RequestType: formContentType, Response: application.TokenExchangeResponse{}},
+		{Method: http.MethodGet, Path: "/token-exchange/policies", OperationID: "listTokenExchangePolicies", Tag: TagTokenExchange,
+			Summary: "Lista as políticas de troca de tokens do tenant", Response: []model.TokenExchangePolicy{}},
+		{Method: http.MethodPost, Path: "/token-exchange/policies", OperationID: "createTokenExchangePolicy", Tag: TagTokenExchange,
+			Summary: "Cria a política de uma audiência: serviços autorizados, escopos máximos, validade e profundidade de delegação",
+			Request: handler.TokenExchangePolicyRequest{}, Response: model.TokenExchangePolicy{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/token-exchange/policies/{id}", OperationID: "getTokenExchangePolicy", Tag: TagTokenExchange,
+			Summary: "Obtém uma política de troca de tokens do tenant", Response: model.TokenExchangePolicy{}},
+		{Method: http.MethodPut, Path: "/token-exchange/policies/{id}", OperationID: "updateTokenExchangePolicy", Tag: TagTokenExchange,
+			Summary: "Substitui uma política de troca de tokens do tenant",
+			Request: handler.TokenExchangePolicyRequest{}, Response: model.TokenExchangePolicy{}},
+		{Method: http.MethodDelete, Path: "/token-exchange/policies/{id}", OperationID: "deleteTokenExchangePolicy", Tag: TagTokenExchange,
+			Summary: "Remove uma política de troca de tokens do tenant", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/token-exchange/events", OperationID: "listTokenExchangeEvents", Tag: TagTokenExchange,
+			Summary: "Lista o registo de auditoria das trocas de tokens concedidas e recusadas do tenant",
+			Query: []QueryParam{
+				{Name: "subject", Type: "string", Description: "Titular dos tokens trocados"},
+				{Name: "actor", Type: "string", Description: "Serviço que pediu a troca"},
+				{Name: "audience", Type: "string", Description: "Audiência pedida"},
+				{Name: "outcome", Type: "string", Description: "Resultado da troca (issued, denied)"},
+				{Name: "since", Type: "string", Description: "Início do período (RFC 3339)"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de registos (máximo 1000)"},
+			},
+			Response: []model.TokenExchangeEvent{}},
+
+		// Contas de serviço dos workloads, com funções, credenciais e ligação a contas de serviço Kubernetes
+		// Os erros da autenticação seguem o formato do RFC 6749 (error e error_description)
+		{Method: http.MethodGet, Path: "/service-accounts", OperationID: "listServiceAccounts", Tag: TagServiceAccounts,
+			Summary:  "Lista as contas de serviço do tenant",
+			Query:    []QueryParam{{Name: "status", Type: "string", Description: "Estado das contas (active, disabled)"}},
+			Response: []model.ServiceAccount{}},
+		{Method: http.MethodPost, Path: "/service-accounts", OperationID: "createServiceAccount", Tag: TagServiceAccounts,
+			Summary: "Cria uma conta de serviço ativa, sem funções nem credenciais",
+			Request: handler.ServiceAccountRequest{}, Response: model.ServiceAccount{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/service-accounts/usage", OperationID: "getServiceAccountUsage", Tag: TagServiceAccounts,
+			Summary: "Resume a utilização das contas de serviço do tenant e identifica as contas inativas",
+			Query: []QueryParam{
+				{Name: "days", Type: "integer", Description: "Dias do período contabilizado (30 por padrão, máximo 365)"},
+				{Name: "dormant", Type: "boolean", Description: "Apenas as contas inativas"},
+			},
+			Response: model.ServiceAccountUsageReport{}},
+		{Method: http.MethodPost, Path: "/service-accounts/token", OperationID: "issueServiceAccountToken", Tag: TagServiceAccounts,
+			Summary: "Autentica uma conta de serviço por chave de API, cliente OIDC, certificado mTLS ou token Kubernetes e emite um token de acesso",
+			Request: handler.ServiceAccountTokenRequest{}, RequestType: formContentType, Response: application.ServiceAccountTokenResponse{}},
+		{Method: http.MethodGet, Path: "/service-accounts/{id}", OperationID: "getServiceAccount", Tag: TagServiceAccounts,
+			Summary: "Obtém uma conta de serviço do tenant", Response: model.ServiceAccount{}},
+		{Method: http.MethodPut, Path: "/service-accounts/{id}", OperationID: "updateServiceAccount", Tag: TagServiceAccounts,
+			Summary: "Altera o nome, a descrição e o estado de uma conta de serviço",
+			Request: handler.ServiceAccountRequest{}, Response: model.ServiceAccount{}},
+		{Method: http.MethodPut, Path: "/service-accounts/{id}/roles/{roleId}", OperationID: "bindServiceAccountRole", Tag: TagServiceAccounts,
+			Summary: "Atribui uma função do tenant à conta de serviço", Response: model.ServiceAccount{}},
+		{Method: http.MethodDelete, Path: "/service-accounts/{id}/roles/{roleId}", OperationID: "unbindServiceAccountRole", Tag: TagServiceAccounts,
+			Summary: "Retira uma função da conta de serviço", Response: model.ServiceAccount{}},
+		{Method: http.MethodPut, Path: "/service-accounts/{id}/workload-identity", OperationID: "bindWorkloadIdentity", Tag: TagServiceAccounts,
+			Summary: "Liga a conta de serviço a uma conta de serviço Kubernetes de um cluster configurado",
+			Request: model.WorkloadIdentityBinding{}, Response: model.ServiceAccount{}},
+		{Method: http.MethodDelete, Path: "/service-accounts/{id}/workload-identity", OperationID: "unbindWorkloadIdentity", Tag: TagServiceAccounts,
+			Summary: "Remove a ligação da conta de serviço ao Kubernetes", Response: model.ServiceAccount{}},
+		{Method: http.MethodGet, Path: "/service-accounts/{id}/credentials", OperationID: "listServiceAccountCredentials", Tag: TagServiceAccounts,
+			Summary: "Lista as credenciais da conta de serviço, sem os segredos", Response: []model.ServiceAccountCredential{}},
+		{Method: http.MethodPost, Path: "/service-accounts/{id}/credentials", OperationID: "issueServiceAccountCredential", Tag: TagServiceAccounts,
+			Summary: "Emite uma chave de API ou um cliente OIDC, ou regista um certificado mTLS; o segredo só é devolvido nesta resposta",
+			Request: handler.ServiceAccountCredentialRequest{}, Response: application.IssuedServiceAccountCredential{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/service-accounts/{id}/credentials/{credentialId}/revoke", OperationID: "revokeServiceAccountCredential", Tag: TagServiceAccounts,
+			Summary: "Revoga uma credencial da conta de serviço", Status: http.StatusNoContent},
+
+		// Campanhas de recertificação de acessos
+		// Os itens por decidir são escalados na data de escalamento e revogados no fim do prazo
+		{Method: http.MethodGet, Path: "/recertification/campaigns", OperationID: "listRecertificationCampaigns", Tag: TagRecertification,
+			Summary:  "Lista as campanhas de recertificação do tenant",
+			Query:    []QueryParam{{Name: "status", Type: "string", Description: "Estado da campanha (active, completed, cancelled)"}},
+			Response: []model.RecertificationCampaign{}},
+		{Method: http.MethodPost, Path: "/recertification/campaigns", OperationID: "createRecertificationCampaign", Tag: TagRecertification,
+			Summary: "Abre uma campanha com um item por atribuição das funções no âmbito e notifica os revisores",
+			Request: handler.RecertificationCampaignRequest{}, Response: model.RecertificationCampaign{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/recertification/campaigns/{id}", OperationID: "getRecertificationCampaign", Tag: TagRecertification,
+			Summary: "Obtém uma campanha de recertificação", Response: model.RecertificationCampaign{}},
+		{Method: http.MethodPost, Path: "/recertification/campaigns/{id}/cancel", OperationID: "cancelRecertificationCampaign", Tag: TagRecertification,
+			Summary: "Cancela uma campanha ativa; as decisões já tomadas mantêm-se",
+			Request: handler.RecertificationCancelRequest{}, Response: model.RecertificationCampaign{}},
+		{Method: http.MethodGet, Path: "/recertification/campaigns/{id}/items", OperationID: "listRecertificationItems", Tag: TagRecertification,
+			Summary: "Lista os itens de revisão de uma campanha",
+			Query: []QueryParam{
+				{Name: "status", Type: "string", Description: "Estado do item (pending, approved, revoked)"},
+				{Name: "reviewer_id", Type: "string", Description: "Revisor atribuído"},
+			},
+			Response: []model.RecertificationItem{}},
+		{Method: http.MethodGet, Path: "/recertification/campaigns/{id}/attestation", OperationID: "getRecertificationAttestation", Tag: TagRecertification,
+			Summary: "Obtém o relatório de atestação de uma campanha concluída, com o digest SHA-256", Response: model.RecertificationAttestation{}},
+		{Method: http.MethodGet, Path: "/recertification/reviews", OperationID: "listRecertificationReviews", Tag: TagRecertification,
+			Summary: "Lista os itens por decidir atribuídos ao usuário autenticado", Response: []model.RecertificationItem{}},
+		{Method: http.MethodPost, Path: "/recertification/items/{id}/decision", OperationID: "decideRecertificationItem", Tag: TagRecertification,
+			Summary: "Aprova ou revoga um acesso; a revogação exige comentário e retira de imediato a atribuição",
+			Request: handler.RecertificationDecisionRequest{}, Response: model.RecertificationItem{}},
+
+		// Atributos personalizados dos usuários
+		// Os atributos válidos são expostos às políticas ABAC e os esquemas com claim são emitidos nos tokens
+		{Method: http.MethodGet, Path: "/user-attribute-schemas", OperationID: "listUserAttributeSchemas", Tag: TagUserAttributes,
+			Summary: "Lista os esquemas dos atributos dos usuários do tenant", Response: []model.UserAttributeSchema{}},
+		{Method: http.MethodPost, Path: "/user-attribute-schemas", OperationID: "createUserAttributeSchema", Tag: TagUserAttributes,
+			Summary: "Cria um esquema de atributo tipado, com restrições e claim opcional nos tokens",
+			Request: handler.UserAttributeSchemaRequest{}, Response: model.UserAttributeSchema{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/user-attribute-schemas/{id}", OperationID: "getUserAttributeSchema", Tag: TagUserAttributes,
+			Summary: "Obtém um esquema de atributo", Response: model.UserAttributeSchema{}},
+		{Method: http.MethodPut, Path: "/user-attribute-schemas/{id}", OperationID: "updateUserAttributeSchema", Tag: TagUserAttributes,
+			Summary: "Altera as restrições, a obrigatoriedade e a claim de um esquema; o nome e o tipo não mudam",
+			Request: handler.UserAttributeSchemaRequest{}, Response: model.UserAttributeSchema{}},
+		{Method: http.MethodDelete, Path: "/user-attribute-schemas/{id}", OperationID: "deleteUserAttributeSchema", Tag: TagUserAttributes,
+			Summary: "Remove um esquema e o atributo de todos os usuários do tenant", Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/users/{userId}/attributes", OperationID: "getUserAttributes", Tag: TagUserAttributes,
+			Summary: "Obtém os atributos personalizados de um usuário", Response: model.UserAttributes{}},
+		{Method: http.MethodPut, Path: "/users/{userId}/attributes", OperationID: "setUserAttributes", Tag: TagUserAttributes,
+			Summary: "Substitui os atributos de um usuário, validados pelos esquemas do tenant",
+			Request: handler.UserAttributesRequest{}, Response: model.UserAttributes{}},
+		{Method: http.MethodGet, Path: "/users/{userId}/claims", OperationID: "getUserClaims", Tag: TagUserAttributes,
+			Summary: "Obtém as claims personalizadas a emitir nos tokens do usuário", Response: handler.UserClaimsResponse{}},
+
+		// Notificações de logins suspeitos
+		// O link "não fui eu" da notificação termina as sessões do usuário e inicia a redefinição da credencial
+		{Method: http.MethodGet, Path: "/login-notifications/settings", OperationID: "getLoginNotificationSettings", Tag: TagLoginNotifications,
+			Summary: "Obtém a configuração das notificações de login do tenant", Response: model.LoginNotificationSettings{}},
+		{Method: http.MethodPut, Path: "/login-notifications/settings", OperationID: "updateLoginNotificationSettings", Tag: TagLoginNotifications,
+			Summary: "Ativa as notificações de login e escolhe os canais, o mercado e o idioma padrão",
+			Request: handler.LoginNotificationSettingsRequest{}, Response: model.LoginNotificationSettings{}},
+		{Method: http.MethodGet, Path: "/login-notifications/templates", OperationID: "listLoginNotificationTemplates", Tag: TagLoginNotifications,
+			Summary: "Lista os modelos de mensagem personalizados do tenant", Response: []model.LoginNotificationTemplate{}},
+		{Method: http.MethodPut, Path: "/login-notifications/templates", OperationID: "saveLoginNotificationTemplate", Tag: TagLoginNotifications,
+			Summary: "Grava o modelo de uma mensagem por canal, mercado e idioma",
+			Request: handler.LoginNotificationTemplateRequest{}, Response: model.LoginNotificationTemplate{}},
+		{Method: http.MethodDelete, Path: "/login-notifications/templates/{id}", OperationID: "deleteLoginNotificationTemplate", Tag: TagLoginNotifications,
+			Summary: "Remove um modelo; a mensagem volta a usar o modelo padrão", Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/login-notifications/respond", OperationID: "respondLoginNotification", Tag: TagLoginNotifications,
+			Summary: "Responde \"não fui eu\": termina as sessões do usuário e envia o link de redefinição da credencial",
+			Request: handler.LoginNotMeRequest{}, Response: application.LoginNotMeResult{}},
+		{Method: http.MethodGet, Path: "/users/{userId}/login-notifications", OperationID: "listUserLoginNotifications", Tag: TagLoginNotifications,
+			Summary: "Lista as notificações de login mais recentes de um usuário", Response: []model.LoginNotification{}},
+
+		// Limites de permissões
+		// As atribuições fora dos limites são recusadas com 403 e registadas como incidentes de severidade alta
+		{Method: http.MethodGet, Path: "/permission-boundaries", OperationID: "listPermissionBoundaries", Tag: TagPermissionBoundaries,
+			Summary: "Lista os limites de permissões das funções e dos administradores delegados do tenant", Response: []model.PermissionBoundary{}},
+		{Method: http.MethodPost, Path: "/permission-boundaries", OperationID: "createPermissionBoundary", Tag: TagPermissionBoundaries,
+			Summary: "Cria o conjunto máximo de permissões de uma função ou de um administrador delegado",
+			Request: handler.PermissionBoundaryRequest{}, Response: model.PermissionBoundary{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/permission-boundaries/{id}", OperationID: "getPermissionBoundary", Tag: TagPermissionBoundaries,
+			Summary: "Obtém um limite de permissões", Response: model.PermissionBoundary{}},
+		{Method: http.MethodPut, Path: "/permission-boundaries/{id}", OperationID: "updatePermissionBoundary", Tag: TagPermissionBoundaries,
+			Summary: "Altera o nome, a descrição e os padrões de um limite; o titular não muda",
+			Request: handler.PermissionBoundaryRequest{}, Response: model.PermissionBoundary{}},
+		{Method: http.MethodDelete, Path: "/permission-boundaries/{id}", OperationID: "deletePermissionBoundary", Tag: TagPermissionBoundaries,
+			Summary: "Remove um limite de permissões", Status: http.StatusNoContent},
+
+		// Operações em massa sobre usuários
+		{Method: http.MethodPost, Path: "/bulk-user-jobs", OperationID: "submitBulkUserJob", Tag: TagBulkUserJobs,
+			Summary: "Regista uma operação em massa sobre os usuários enviados, um por linha em NDJSON " +
+				"(campos user_id, role_id, reason, expires_at) ou em CSV (text/csv) com cabeçalho; a execução é assíncrona",
+			Query: []QueryParam{
+				{Name: "operation", Type: "string", Description: "Operação: disable, enable, assign_role ou revoke_role"},
+				{Name: "role_id", Type: "string", Description: "Função dos itens que não a indicam"},
+				{Name: "reason", Type: "string", Description: "Motivo dos itens que não o indicam; obrigatório na desativação"},
+				{Name: "expires_at", Type: "string", Description: "Expiração (RFC 3339) das atribuições que não a indicam"},
+			},
+			Request: "", RequestType: ndjsonContentType, Response: model.BulkUserJob{}, Status: http.StatusAccepted},
+		{Method: http.MethodGet, Path: "/bulk-user-jobs", OperationID: "listBulkUserJobs", Tag: TagBulkUserJobs,
+			Summary: "Lista as operações em massa do tenant, da mais recente para a mais antiga", Response: []model.BulkUserJob{}},
+		{Method: http.MethodGet, Path: "/bulk-user-jobs/{id}", OperationID: "getBulkUserJob", Tag: TagBulkUserJobs,
+			Summary: "Obtém o estado e os contadores de uma operação em massa", Response: model.BulkUserJob{}},
+		{Method: http.MethodGet, Path: "/bulk-user-jobs/{id}/items", OperationID: "listBulkUserJobItems", Tag: TagBulkUserJobs,
+			Summary: "Lista o resultado de cada usuário de uma operação em massa, pela linha do conteúdo enviado",
+			Query: []QueryParam{
+				{Name: "status", Type: "string", Description: "Resultado do item (pending, succeeded, skipped ou failed)"},
+				{Name: "after", Type: "integer", Description: "Linha next_after da página anterior"},
+				{Name: "limit", Type: "integer", Description: "Número máximo de itens (padrão 100, máximo 1000)"},
+			},
+			Response: application.BulkUserJobItemPage{}},
+		{Method: http.MethodPost, Path: "/bulk-user-jobs/{id}/resume", OperationID: "resumeBulkUserJob", Tag: TagBulkUserJobs,
+			Summary:  "Retoma uma operação em massa falhada ou concluída com erros, voltando a processar os itens falhados",
+			Response: model.BulkUserJob{}, Status: http.StatusAccepted},
+
+		// Identidade visual e modelos de email
+		// O HTML dos modelos é sanitizado; as versões publicadas substituem os modelos padrão por idioma
+		{Method: http.MethodGet, Path: "/branding", OperationID: "getTenantBranding", Tag: TagTenantBranding,
+			Summary: "Obtém a identidade visual do tenant usada nos emails", Response: model.TenantBranding{}},
+		{Method: http.MethodPut, Path: "/branding", OperationID: "updateTenantBranding", Tag: TagTenantBranding,
+			Summary: "Altera o nome, o logótipo, as cores e os contactos de suporte apresentados nos emails",
+			Request: handler.TenantBrandingRequest{}, Response: model.TenantBranding{}},
+		{Method: http.MethodGet, Path: "/email-templates/variables", OperationID: "listEmailTemplateVariables", Tag: TagTenantBranding,
+			Summary: "Lista as variáveis disponíveis nos modelos de cada email", Response: []application.EmailTemplateKindVariables{}},
+		{Method: http.MethodPost, Path: "/email-templates/preview", OperationID: "previewEmail", Tag: TagTenantBranding,
+			Summary: "Pré-visualiza um email com valores de exemplo, a partir de uma versão, de um modelo enviado ou da versão em uso",
+			Request: handler.EmailPreviewRequest{}, Response: model.RenderedEmail{}},
+		{Method: http.MethodGet, Path: "/email-templates", OperationID: "listEmailTemplates", Tag: TagTenantBranding,
+			Summary: "Lista as versões dos modelos de email do tenant",
+			Query: []QueryParam{
+				{Name: "kind", Type: "string", Description: "Email (invite, password_reset ou mfa_enrollment)"},
+				{Name: "locale", Type: "string", Description: "Idioma (pt-PT, pt-BR, en, es ou fr)"},
+				{Name: "status", Type: "string", Description: "Estado da versão (draft, published ou archived)"},
+			},
+			Response: []model.EmailTemplate{}},
+		{Method: http.MethodPost, Path: "/email-templates", OperationID: "createEmailTemplate", Tag: TagTenantBranding,
+			Summary: "Grava uma nova versão do modelo de um email num idioma como rascunho",
+			Request: handler.EmailTemplateRequest{}, Response: model.EmailTemplate{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/email-templates/{id}", OperationID: "getEmailTemplate", Tag: TagTenantBranding,
+			Summary: "Obtém uma versão de um modelo de email", Response: model.EmailTemplate{}},
+		{Method: http.MethodPost, Path: "/email-templates/{id}/publish", OperationID: "publishEmailTemplate", Tag: TagTenantBranding,
+			Summary:  "Publica uma versão no lugar da versão publicada do mesmo email e idioma; publicar uma versão antiga repõe-na",
+			Response: model.EmailTemplate{}},
+		{Method: http.MethodPost, Path: "/email-templates/{id}/archive", OperationID: "archiveEmailTemplate", Tag: TagTenantBranding,
+			Summary:  "Arquiva uma versão; arquivar a versão publicada repõe o modelo padrão",
+			Response: model.EmailTemplate{}},
+
+		// Registo de dispositivos
+		// Os dispositivos são registados nos logins; um dispositivo usado por várias contas é registado como incidente
+		{Method: http.MethodGet, Path: "/device-policy", OperationID: "getDevicePolicy", Tag: TagDevices,
+			Summary: "Obtém a política de dispositivos do tenant", Response: model.DevicePolicy{}},
+		{Method: http.MethodPut, Path: "/device-policy", OperationID: "updateDevicePolicy", Tag: TagDevices,
+			Summary: "Ativa a confiança em dispositivos e altera a validade, o máximo por usuário e o limiar de partilha",
+			Request: handler.DevicePolicyRequest{}, Response: model.DevicePolicy{}},
+		{Method: http.MethodGet, Path: "/users/{userId}/devices", OperationID: "listUserDevices", Tag: TagDevices,
+			Summary: "Lista os dispositivos de um usuário, do usado mais recentemente para o mais antigo", Response: []model.Device{}},
+		{Method: http.MethodPost, Path: "/users/{userId}/devices/trust-check", OperationID: "checkUserDeviceTrust", Tag: TagDevices,
+			Summary: "Indica se um login do usuário a partir do dispositivo pode dispensar o segundo fator",
+			Request: handler.DeviceTrustCheckRequest{}, Response: model.DeviceTrustDecision{}},
+		{Method: http.MethodPut, Path: "/users/{userId}/devices/{id}", OperationID: "renameUserDevice", Tag: TagDevices,
+			Summary: "Altera o nome apresentado de um dispositivo",
+			Request: handler.DeviceRenameRequest{}, Response: model.Device{}},
+		{Method: http.MethodPost, Path: "/users/{userId}/devices/{id}/trust", OperationID: "trustUserDevice", Tag: TagDevices,
+			Summary:  "Marca um dispositivo como de confiança pela validade da política do tenant",
+			Response: model.Device{}},
+		{Method: http.MethodDelete, Path: "/users/{userId}/devices/{id}/trust", OperationID: "untrustUserDevice", Tag: TagDevices,
+			Summary:  "Retira a confiança num dispositivo; o login seguinte volta a exigir MFA",
+			Response: model.Device{}},
+		{Method: http.MethodPost, Path: "/users/{userId}/devices/{id}/revoke", OperationID: "revokeUserDevice", Tag: TagDevices,
+			Summary:  "Revoga um dispositivo e retira-lhe a confiança",
+			Response: model.Device{}},
+	}
+}
+
+// HealthRoutes retorna as rotas de verificação de saúde, servidas fora de BasePath
+func HealthRoutes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/health", OperationID: "health", Tag: TagHealth,
+			Summary: "Verifica se o serviço está ativo", Response: healthResponse{}},
+		{Method: http.MethodGet, Path: "/ready", OperationID: "ready", Tag: TagHealth,
+			Summary: "Verifica se o serviço está pronto para receber tráfego", Response: healthResponse{}},
+	}
+}
@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// schemaRefPrefix é o prefixo das referências a schemas dos componentes
+const schemaRefPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Registry gera schemas a partir de tipos Go e os regista como componentes
+// Estruturas nomeadas tornam-se componentes referenciados por $ref
+type Registry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewRegistry cria um registo de schemas vazio
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas retorna os schemas registados, indexados pelo nome do componente
+func (r *Registry) Schemas() map[string]*Schema {
+	return r.schemas
+}
+
+// SchemaFor retorna o schema do tipo do valor indicado
+func (r *Registry) SchemaFor(v interface{}) *Schema {
+	return r.schemaOf(reflect.TypeOf(v))
+}
+
+// Register regista um schema construído manualmente e retorna a sua referência
+func (r *Registry) Register(name string, schema *Schema) *Schema {
+	r.schemas[name] = schema
+	return Ref(name)
+}
+
+// Ref retorna uma referência para o componente indicado
+func Ref(name string) *Schema {
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// RefName retorna o nome do componente de uma referência
+func RefName(schema *Schema) string {
+	return strings.TrimPrefix(schema.Ref, schemaRefPrefix)
+}
+
+// schemaOf converte um tipo Go no schema correspondente à sua serialização JSON
+func (r *Registry) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+
+	if t.Kind() != reflect.Struct && reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		return r.structRef(t)
+	}
+
+	return &Schema{}
+}
+
+// structRef regista uma estrutura como componente e retorna a sua referência
+func (r *Registry) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return r.structSchema(t)
+	}
+
+	if name, ok := r.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := componentName(t)
+	if _, taken := r.schemas[name]; taken {
+		// Tipos homónimos de pacotes diferentes recebem o nome do pacote como prefixo
+		name = exportName(pathBase(t.PkgPath())) + name
+	}
+
+	// Registar o nome antes de descer nos campos permite tipos recursivos
+	r.names[t] = name
+	r.schemas[name] = &Schema{}
+	*r.schemas[name] = *r.structSchema(t)
+
+	return Ref(name)
+}
+
+// structSchema gera o schema de objeto a partir dos campos exportados e das tags json
+func (r *Registry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonField(field)
+		if skip {
+			continue
+		}
+
+		// Estruturas embutidas sem nome JSON têm os campos promovidos
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := r.structSchema(embedded)
+				for prop, propSchema := range inner.Properties {
+					schema.Properties[prop] = propSchema
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = r.schemaOf(field.Type)
+		if !omitEmpty && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// jsonField interpreta a tag json de um campo
+func jsonField(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+// componentName deriva o nome do componente a partir do nome do tipo
+func componentName(t reflect.Type) string {
+	return exportName(t.Name())
+}
+
+// exportName converte um identificador para a forma exportada
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// pathBase retorna o último segmento de um caminho de pacote
+func pathBase(pkgPath string) string {
+	if i := strings.LastIndex(pkgPath, "/"); i >= 0 {
+		return pkgPath[i+1:]
+	}
+	return pkgPath
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"innovabiz/iam/identity-service/client"
+	"innovabiz/iam/identity-service/internal/interface/api/handler"
+	"innovabiz/iam/identity-service/internal/interface/api/openapi"
+)
+
+// registeredRoutes retorna as rotas registadas pelo RoleHandler no formato "MÉTODO caminho"
+func registeredRoutes(t *testing.T) []string {
+	router := mux.NewRouter()
+	api := router.PathPrefix(openapi.BasePath).Subrouter()
+	handler.NewRoleHandler(nil, zerolog.Nop(), noop.NewTracerProvider().Tracer("")).RegisterRoutes(api)
+
+	var routes []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	sort.Strings(routes)
+	return routes
+}
+
+// TestDocumentCoversRegisteredRoutes verifica que o documento acompanha as rotas do RoleHandler
+func TestDocumentCoversRegisteredRoutes(t *testing.T) {
+	doc := openapi.Build()
+
+	var documented []string
+	doc.Operations(func(path, method string, op *openapi.Operation) {
+		if op.Tags[0] != openapi.TagHealth {
+			documented = append(documented, method+" "+path)
+		}
+	})
+	sort.Strings(documented)
+
+	assert.Equal(t, registeredRoutes(t), documented)
+}
+
+// TestDocumentIsValid verifica a versão, a unicidade dos operationId e as referências a schemas
+func TestDocumentIsValid(t *testing.T) {
+	doc := openapi.Build()
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	operationIDs := make(map[string]bool)
+	doc.Operations(func(path, method string, op *openapi.Operation) {
+		assert.False(t, operationIDs[op.OperationID], "operationId duplicado: %s", op.OperationID)
+		operationIDs[op.OperationID] = true
+	})
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	var checkRefs func(v interface{})
+	checkRefs = func(v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := value["$ref"].(string); ok {
+				name := openapi.RefName(&openapi.Schema{Ref: ref})
+				if name != ref {
+					assert.Contains(t, doc.Components.Schemas, name, "referência inexistente: %s", ref)
+				}
+			}
+			for _, child := range value {
+				checkRefs(child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				checkRefs(child)
+			}
+		}
+	}
+	checkRefs(raw)
+}
+
+// TestPublishedDocumentIsUpToDate verifica que api/openapi.json foi regenerado após alterações
+func TestPublishedDocumentIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../../../../api/openapi.json")
+	require.NoError(t, err)
+
+	current, err := json.MarshalIndent(openapi.Build(), "", "  ")
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(current), string(published), "execute go generate ./client para atualizar o contrato")
+}
+
+// TestHandlerServesDocument verifica o endpoint /openapi.json e o uso do cliente gerado
+func TestHandlerServesDocument(t *testing.T) {
+	rr := httptest.NewRecorder()
+	openapi.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.NotNil(t, doc.Paths[openapi.BasePath+"/roles/{id}"].Get)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":404,"code":"not_found","message":"Recurso não encontrado","locale":"pt-BR"}`))
+	}))
+	defer server.Close()
+
+	_, err := client.New(server.URL).GetRole(context.Background(), uuid.New())
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "not_found", apiErr.Body.Code)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
@@ -19,16 +20,51 @@ import (
 
 	"innovabiz/iam/identity-service/internal/application"
 	"innovabiz/iam/identity-service/internal/interface/api/handler"
+	"innovabiz/iam/identity-service/internal/interface/api/i18n"
+	"innovabiz/iam/identity-service/internal/interface/api/openapi"
 	"innovabiz/iam/identity-service/internal/interface/middleware"
 )
 
 // Server representa o servidor HTTP da aplicação
 type Server struct {
-	router      *mux.Router
-	httpServer  *http.Server
-	logger      zerolog.Logger
-	tracer      trace.Tracer
-	roleService application.RoleService
+	router        *mux.Router
+	httpServer    *http.Server
+	logger        zerolog.Logger
+	tracer        trace.Tracer
+	roleService          application.RoleService
+	impactService        application.ImpactAnalysisService
+	historyService       application.RoleHistoryService
+	accessRequestService application.AccessRequestService
+	templateService      application.RoleTemplateService
+	samlService          application.SAMLFederationService
+	anomalyService       application.AuditAnomalyService
+	exportService        application.TenantExportService
+	decisionService      application.PermissionDecisionAuditService
+	miningService        application.RoleMiningService
+	passwordlessService  application.PasswordlessService
+	networkPolicyService application.NetworkPolicyService
+	networkPolicyConfig  *middleware.NetworkPolicyConfig
+	trustedProxies       []string
+	sessionPolicyService application.SessionPolicyService
+	sessionPolicyConfig  *middleware.SessionPolicyConfig
+	roleMetadataPolicies application.RoleMetadataPolicyService
+	emergencyAccess      application.EmergencyAccessService
+	emergencyConfig      *middleware.EmergencyAccessConfig
+	accountLinkService   application.AccountLinkService
+	legalConsentService  application.LegalConsentService
+	tokenExchange        application.TokenExchangeService
+	auditLogService      application.AuditLogService
+	serviceAccounts      application.ServiceAccountService
+	recertification      application.RecertificationService
+	userAttributes       application.UserAttributeService
+	loginNotifications   application.LoginNotificationService
+	boundaries           application.PermissionBoundaryService
+	bulkUserJobs         application.BulkUserJobService
+	tenantBranding       application.TenantBrandingService
+	devices              application.DeviceService
+	authConfig           *middleware.AuthConfig
+	stepUpConfig         *middleware.StepUpConfig
+	authzConfig          *middleware.AuthzConfig
 	// Adicionar outros serviços conforme necessário
 }
 
@@ -100,6 +136,9 @@ func New(config Config, roleService application.RoleService, logger zerolog.Logg
 	// Configurar recuperação de pânico
 	router.Use(middleware.RecoveryMiddleware(logger))
 
+	// Negociar o idioma das mensagens via Accept-Language
+	router.Use(i18n.Middleware)
+
 	// Configurar servidor HTTP
 	httpServer := &http.Server{
 		Addr:         ":" + config.Port,
@@ -118,6 +157,179 @@ func New(config Config, roleService application.RoleService, logger zerolog.Logg
 	}
 }
 
+// SetImpactAnalysisService configura o serviço de análise de impacto de alterações de acesso
+func (s *Server) SetImpactAnalysisService(impactService application.ImpactAnalysisService) {
+	s.impactService = impactService
+}
+
+// SetRoleHistoryService configura o serviço de histórico imutável de funções
+func (s *Server) SetRoleHistoryService(historyService application.RoleHistoryService) {
+	s.historyService = historyService
+}
+
+// SetAccessRequestService configura o serviço de pedidos de acesso em autoatendimento
+func (s *Server) SetAccessRequestService(accessRequestService application.AccessRequestService) {
+	s.accessRequestService = accessRequestService
+}
+
+// SetRoleTemplateService configura o serviço da biblioteca de modelos de função
+func (s *Server) SetRoleTemplateService(templateService application.RoleTemplateService) {
+	s.templateService = templateService
+}
+
+// SetSAMLFederationService configura o serviço de federação com fornecedores de identidade SAML
+func (s *Server) SetSAMLFederationService(samlService application.SAMLFederationService) {
+	s.samlService = samlService
+}
+
+// SetAuditAnomalyService configura o serviço de deteção de anomalias e triagem de incidentes de segurança
+func (s *Server) SetAuditAnomalyService(anomalyService application.AuditAnomalyService) {
+	s.anomalyService = anomalyService
+}
+
+// SetTenantExportService configura o serviço de exportação de dados dos tenants
+func (s *Server) SetTenantExportService(exportService application.TenantExportService) {
+	s.exportService = exportService
+}
+
+// SetPermissionDecisionAuditService configura o serviço de auditoria das decisões de autorização
+// Quando a autorização por OPA está ativa, as suas decisões são registadas neste serviço
+func (s *Server) SetPermissionDecisionAuditService(decisionService application.PermissionDecisionAuditService) {
+	s.decisionService = decisionService
+}
+
+// SetAuditLogService configura o serviço de consulta, apenas de leitura, dos registos de auditoria
+func (s *Server) SetAuditLogService(auditLogService application.AuditLogService) {
+	s.auditLogService = auditLogService
+}
+
+// SetRoleMiningService configura o serviço de sugestões de função mineradas das permissões diretas
+func (s *Server) SetRoleMiningService(miningService application.RoleMiningService) {
+	s.miningService = miningService
+}
+
+// SetPasswordlessService configura o serviço de autenticação sem senha por link mágico e código por email
+func (s *Server) SetPasswordlessService(passwordlessService application.PasswordlessService) {
+	s.passwordlessService = passwordlessService
+}
+
+// SetNetworkPolicyService configura o serviço de políticas de rede por tenant e de acessos de emergência
+func (s *Server) SetNetworkPolicyService(networkPolicyService application.NetworkPolicyService) {
+	s.networkPolicyService = networkPolicyService
+}
+
+// SetNetworkPolicyConfig ativa a aplicação das políticas de rede dos tenants aos pedidos da API
+// Sem avaliador na configuração, é usado o serviço de políticas de rede
+func (s *Server) SetNetworkPolicyConfig(config middleware.NetworkPolicyConfig) {
+	s.networkPolicyConfig = &config
+}
+
+// SetTrustedProxies configura os blocos CIDR dos proxies de confiança usados pelos handlers para
+// obter o endereço do cliente de X-Forwarded-For
+func (s *Server) SetTrustedProxies(cidrs []string) {
+	s.trustedProxies = cidrs
+}
+
+// SetSessionPolicyService configura o serviço de políticas de sessão por tenant
+func (s *Server) SetSessionPolicyService(sessionPolicyService application.SessionPolicyService) {
+	s.sessionPolicyService = sessionPolicyService
+}
+
+// SetSessionPolicyConfig ativa a aplicação das políticas de sessão dos tenants aos pedidos da API
+// Sem verificador na configuração, é usado o serviço de políticas de sessão
+func (s *Server) SetSessionPolicyConfig(config middleware.SessionPolicyConfig) {
+	s.sessionPolicyConfig = &config
+}
+
+// SetRoleMetadataPolicyService configura as políticas de acesso às chaves dos metadados das funções
+func (s *Server) SetRoleMetadataPolicyService(roleMetadataPolicyService application.RoleMetadataPolicyService) {
+	s.roleMetadataPolicies = roleMetadataPolicyService
+}
+
+// SetEmergencyAccessService configura o serviço das contas de emergência (break-glass)
+func (s *Server) SetEmergencyAccessService(emergencyAccessService application.EmergencyAccessService) {
+	s.emergencyAccess = emergencyAccessService
+}
+
+// SetEmergencyAccessConfig ativa a selagem e a gravação dos pedidos das contas de emergência
+// Sem autorizador na configuração, é usado o serviço das contas de emergência
+func (s *Server) SetEmergencyAccessConfig(config middleware.EmergencyAccessConfig) {
+	s.emergencyConfig = &config
+}
+
+// SetAccountLinkService configura o serviço de ligação de contas entre fornecedores de identidade
+func (s *Server) SetAccountLinkService(accountLinkService application.AccountLinkService) {
+	s.accountLinkService = accountLinkService
+}
+
+// SetLegalConsentService configura o serviço de aceitação dos documentos legais do tenant
+func (s *Server) SetLegalConsentService(legalConsentService application.LegalConsentService) {
+	s.legalConsentService = legalConsentService
+}
+
+// SetTokenExchangeService configura o serviço de troca de tokens para delegação entre serviços
+func (s *Server) SetTokenExchangeService(tokenExchangeService application.TokenExchangeService) {
+	s.tokenExchange = tokenExchangeService
+}
+
+// SetServiceAccountService configura o serviço das contas de serviço dos workloads
+func (s *Server) SetServiceAccountService(serviceAccountService application.ServiceAccountService) {
+	s.serviceAccounts = serviceAccountService
+}
+
+// SetRecertificationService configura o serviço das campanhas de recertificação de acessos
+func (s *Server) SetRecertificationService(recertificationService application.RecertificationService) {
+	s.recertification = recertificationService
+}
+
+// SetUserAttributeService configura o serviço dos atributos personalizados dos usuários
+// Os atributos são também expostos às políticas OPA quando a autorização estiver configurada
+func (s *Server) SetUserAttributeService(userAttributeService application.UserAttributeService) {
+	s.userAttributes = userAttributeService
+}
+
+// SetLoginNotificationService configura o serviço das notificações de logins suspeitos
+func (s *Server) SetLoginNotificationService(loginNotificationService application.LoginNotificationService) {
+	s.loginNotifications = loginNotificationService
+}
+
+// SetPermissionBoundaryService configura o serviço dos limites de permissões
+// Os limites são também aplicados pelas políticas OPA quando a autorização estiver configurada
+func (s *Server) SetPermissionBoundaryService(permissionBoundaryService application.PermissionBoundaryService) {
+	s.boundaries = permissionBoundaryService
+}
+
+// SetBulkUserJobService configura o serviço das operações em massa sobre usuários
+func (s *Server) SetBulkUserJobService(bulkUserJobService application.BulkUserJobService) {
+	s.bulkUserJobs = bulkUserJobService
+}
+
+// SetTenantBrandingService configura o serviço da identidade visual e dos modelos de email dos tenants
+func (s *Server) SetTenantBrandingService(tenantBrandingService application.TenantBrandingService) {
+	s.tenantBranding = tenantBrandingService
+}
+
+// SetDeviceService configura o serviço do registo de dispositivos dos usuários
+func (s *Server) SetDeviceService(deviceService application.DeviceService) {
+	s.devices = deviceService
+}
+
+// SetAuthConfig ativa a autenticação das rotas da API, que injeta o tenant, o mercado e o ator no contexto
+// Os repositórios recusam as consultas sem tenant, pelo que as rotas que os usam precisam desta configuração
+func (s *Server) SetAuthConfig(config middleware.AuthConfig) {
+	s.authConfig = &config
+}
+
+// SetStepUpConfig ativa a exigência de step-up de MFA nas rotas sensíveis da API
+func (s *Server) SetStepUpConfig(config middleware.StepUpConfig) {
+	s.stepUpConfig = &config
+}
+
+// SetAuthzConfig ativa a autorização das rotas da API por políticas OPA
+func (s *Server) SetAuthzConfig(config middleware.AuthzConfig) {
+	s.authzConfig = &config
+}
+
 // Start inicia o servidor HTTP
 func (s *Server) Start() error {
 	s.registerRoutes()
@@ -148,9 +360,57 @@ func (s *Server) registerRoutes() {
 func (s *Server) registerAPIRoutes() {
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 	
-	// Registrar middleware de autenticação (JWT) para as rotas da API
-	// Em um ambiente de produção, descomente esta linha e implemente o middleware
-	// api.Use(middleware.AuthenticationMiddleware())
+	// Autenticar os pedidos e propagar o tenant, o mercado e o ator até aos repositórios
+	if s.authConfig != nil {
+		api.Use(middleware.AuthMiddleware(s.logger, *s.authConfig))
+	}
+
+	// Recusar os tokens e as sessões que excedem a política de sessão do tenant
+	if s.sessionPolicyConfig != nil {
+		sessionPolicyConfig := *s.sessionPolicyConfig
+		if sessionPolicyConfig.Enforcer == nil && s.sessionPolicyService != nil {
+			sessionPolicyConfig.Enforcer = s.sessionPolicyService
+		}
+		api.Use(middleware.SessionPolicyMiddleware(s.logger, sessionPolicyConfig))
+	}
+
+	// Recusar os pedidos de endereços bloqueados pelas políticas de rede do tenant
+	if s.networkPolicyConfig != nil {
+		networkPolicyConfig := *s.networkPolicyConfig
+		if networkPolicyConfig.Evaluator == nil && s.networkPolicyService != nil {
+			networkPolicyConfig.Evaluator = s.networkPolicyService
+		}
+		api.Use(middleware.NetworkPolicyMiddleware(s.logger, networkPolicyConfig))
+	}
+
+	// Recusar os pedidos das contas de emergência seladas e gravar os das ativações em vigor
+	if s.emergencyConfig != nil {
+		emergencyConfig := *s.emergencyConfig
+		if emergencyConfig.Authorizer == nil && s.emergencyAccess != nil {
+			emergencyConfig.Authorizer = s.emergencyAccess
+		}
+		api.Use(middleware.EmergencyAccessMiddleware(s.logger, emergencyConfig))
+	}
+
+	// Exigir MFA recente conforme o mercado nas operações sensíveis
+	if s.stepUpConfig != nil {
+		api.Use(middleware.StepUpMiddleware(s.logger, *s.stepUpConfig))
+	}
+
+	// Autorizar as operações por políticas OPA, auditando as decisões
+	if s.authzConfig != nil {
+		authzConfig := *s.authzConfig
+		if authzConfig.DecisionRecorder == nil && s.decisionService != nil {
+			authzConfig.DecisionRecorder = s.decisionService
+		}
+		if authzConfig.AttributeResolver == nil && s.userAttributes != nil {
+			authzConfig.AttributeResolver = s.userAttributes
+		}
+		if authzConfig.BoundaryEnforcer == nil && s.boundaries != nil {
+			authzConfig.BoundaryEnforcer = s.boundaries
+		}
+		api.Use(middleware.AuthorizationMiddleware(s.logger, authzConfig))
+	}
 	
 	// Registrar handlers
 	s.registerRoleHandler(api)
@@ -164,6 +424,87 @@ func (s *Server) registerAPIRoutes() {
 // registerRoleHandler registra as rotas do RoleHandler
 func (s *Server) registerRoleHandler(router *mux.Router) {
 	roleHandler := handler.NewRoleHandler(s.roleService, s.logger, s.tracer)
+	if s.impactService != nil {
+		roleHandler.SetImpactAnalysisService(s.impactService)
+	}
+	if s.historyService != nil {
+		roleHandler.SetRoleHistoryService(s.historyService)
+	}
+	if s.accessRequestService != nil {
+		roleHandler.SetAccessRequestService(s.accessRequestService)
+	}
+	if s.templateService != nil {
+		roleHandler.SetRoleTemplateService(s.templateService)
+	}
+	if s.samlService != nil {
+		roleHandler.SetSAMLFederationService(s.samlService)
+	}
+	if s.anomalyService != nil {
+		roleHandler.SetAuditAnomalyService(s.anomalyService)
+	}
+	if s.exportService != nil {
+		roleHandler.SetTenantExportService(s.exportService)
+	}
+	if s.decisionService != nil {
+		roleHandler.SetPermissionDecisionAuditService(s.decisionService)
+	}
+	if s.auditLogService != nil {
+		roleHandler.SetAuditLogService(s.auditLogService)
+	}
+	if s.miningService != nil {
+		roleHandler.SetRoleMiningService(s.miningService)
+	}
+	if s.passwordlessService != nil {
+		roleHandler.SetPasswordlessService(s.passwordlessService)
+	}
+	if s.networkPolicyService != nil {
+		roleHandler.SetNetworkPolicyService(s.networkPolicyService)
+	}
+	if len(s.trustedProxies) > 0 {
+		roleHandler.SetTrustedProxies(s.trustedProxies)
+	}
+	if s.sessionPolicyService != nil {
+		roleHandler.SetSessionPolicyService(s.sessionPolicyService)
+	}
+	if s.roleMetadataPolicies != nil {
+		roleHandler.SetRoleMetadataPolicyService(s.roleMetadataPolicies)
+	}
+	if s.emergencyAccess != nil {
+		roleHandler.SetEmergencyAccessService(s.emergencyAccess)
+	}
+	if s.accountLinkService != nil {
+		roleHandler.SetAccountLinkService(s.accountLinkService)
+	}
+	if s.legalConsentService != nil {
+		roleHandler.SetLegalConsentService(s.legalConsentService)
+	}
+	if s.tokenExchange != nil {
+		roleHandler.SetTokenExchangeService(s.tokenExchange)
+	}
+	if s.serviceAccounts != nil {
+		roleHandler.SetServiceAccountService(s.serviceAccounts)
+	}
+	if s.recertification != nil {
+		roleHandler.SetRecertificationService(s.recertification)
+	}
+	if s.userAttributes != nil {
+		roleHandler.SetUserAttributeService(s.userAttributes)
+	}
+	if s.loginNotifications != nil {
+		roleHandler.SetLoginNotificationService(s.loginNotifications)
+	}
+	if s.boundaries != nil {
+		roleHandler.SetPermissionBoundaryService(s.boundaries)
+	}
+	if s.bulkUserJobs != nil {
+		roleHandler.SetBulkUserJobService(s.bulkUserJobs)
+	}
+	if s.tenantBranding != nil {
+		roleHandler.SetTenantBrandingService(s.tenantBranding)
+	}
+	if s.devices != nil {
+		roleHandler.SetDeviceService(s.devices)
+	}
 	roleHandler.RegisterRoutes(router)
 }
 
@@ -181,12 +522,15 @@ func (s *Server) registerHealthCheckRoutes() {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, `{"status":"READY"}`)
 	}).Methods(http.MethodGet)
+
+	// Métricas Prometheus, incluindo os pedidos recusados pelas políticas de rede
+	s.router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
 }
 
 // registerDocsRoutes registra as rotas de documentação da API
+// O documento OpenAPI é gerado a partir das rotas e DTOs dos handlers
 func (s *Server) registerDocsRoutes() {
-	// TODO: Implementar quando tivermos documentação OpenAPI
-	// s.router.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", http.FileServer(http.Dir("./docs"))))
+	s.router.Handle("/openapi.json", openapi.Handler()).Methods(http.MethodGet)
 }
 
 // statusWriter é um wrapper para http.ResponseWriter para capturar o código de status da resposta
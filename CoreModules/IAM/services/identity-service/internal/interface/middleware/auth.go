@@ -16,16 +16,27 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
 )
 
 // Chaves do contexto para informações do usuário autenticado
 type contextKey string
 
 const (
-	TenantIDContextKey contextKey = "tenant_id"
-	UserIDContextKey   contextKey = "user_id"
-	UsernameContextKey contextKey = "username"
-	RolesContextKey    contextKey = "roles"
+	TenantIDContextKey       contextKey = "tenant_id"
+	UserIDContextKey         contextKey = "user_id"
+	UsernameContextKey       contextKey = "username"
+	RolesContextKey          contextKey = "roles"
+	MarketContextKey         contextKey = "market"
+	MFALevelContextKey       contextKey = "mfa_level"
+	AuthTimeContextKey       contextKey = "auth_time"
+	SessionIDContextKey      contextKey = "session_id"
+	TokenIssuedAtContextKey  contextKey = "token_issued_at"
+	TokenExpiresAtContextKey contextKey = "token_expires_at"
+	ScopesContextKey         contextKey = "scopes"
+	ActorContextKey          contextKey = "actor"
 )
 
 // Claims representa as reivindicações (claims) customizadas do JWT
@@ -37,6 +48,13 @@ type Claims struct {
 	Email     string   `json:"email,omitempty"`
 	FirstName string   `json:"given_name,omitempty"`
 	LastName  string   `json:"family_name,omitempty"`
+	Market    string   `json:"market,omitempty"`
+	MFALevel  string   `json:"mfa_level,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"` // Momento da última autenticação (OIDC)
+	SessionID string   `json:"sid,omitempty"` // Sessão a que o token pertence (OIDC)
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     *string  `json:"scope,omitempty"` // Escopos separados por espaços, nos tokens delegados (RFC 8693)
+	Actor     *model.ActorClaim `json:"act,omitempty"` // Serviço que age em nome do titular (RFC 8693)
 }
 
 // AuthConfig representa a configuração do middleware de autenticação
@@ -105,12 +123,40 @@ func AuthMiddleware(logger zerolog.Logger, config AuthConfig) func(http.Handler)
 					userID = "00000000-0000-0000-0000-000000000000" // Usuário padrão para desenvolvimento
 				}
 
+				// Os repositórios recusam as consultas sem tenant; um tenant simulado inválido é rejeitado
+				tenantUUID, err := uuid.Parse(tenantID)
+				if err != nil || tenantUUID == uuid.Nil {
+					span.SetStatus(codes.Error, "TenantID inválido")
+					handleAuthError(w, http.StatusUnauthorized, "invalid_tenant", "TenantID inválido", logger)
+					return
+				}
+				userUUID, err := uuid.Parse(userID)
+				if err != nil {
+					span.SetStatus(codes.Error, "UserID inválido")
+					handleAuthError(w, http.StatusUnauthorized, "invalid_user", "UserID inválido", logger)
+					return
+				}
+
 				// Adicionar informações ao contexto
 				ctx = context.WithValue(ctx, TenantIDContextKey, tenantID)
 				ctx = context.WithValue(ctx, UserIDContextKey, userID)
 				ctx = context.WithValue(ctx, UsernameContextKey, "dev_user")
 				ctx = context.WithValue(ctx, RolesContextKey, []string{"admin"})
 
+				// Sessão simulada com MFA forte e autenticação recente, sobreponível por headers
+				mfaLevel := r.Header.Get("X-MFA-Level")
+				if mfaLevel == "" {
+					mfaLevel = MFALevelHigh
+				}
+				ctx = context.WithValue(ctx, MarketContextKey, r.Header.Get("X-Market"))
+				ctx = context.WithValue(ctx, MFALevelContextKey, mfaLevel)
+				ctx = context.WithValue(ctx, AuthTimeContextKey, time.Now())
+				ctx = withRequestContext(ctx, tenantUUID, r.Header.Get("X-Market"), requestctx.Actor{
+					UserID:   userUUID,
+					Username: "dev_user",
+					Roles:    []string{"admin"},
+				})
+
 				// Continuar com o processamento da requisição
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
@@ -224,6 +270,47 @@ func AuthMiddleware(logger zerolog.Logger, config AuthConfig) func(http.Handler)
 			ctx = context.WithValue(ctx, UserIDContextKey, userID)
 			ctx = context.WithValue(ctx, UsernameContextKey, claims.Username)
 			ctx = context.WithValue(ctx, RolesContextKey, claims.Roles)
+			ctx = context.WithValue(ctx, MarketContextKey, claims.Market)
+			ctx = context.WithValue(ctx, MFALevelContextKey, claims.MFALevel)
+
+			// Sem auth_time, considerar a emissão do token como momento da autenticação
+			if claims.AuthTime != nil {
+				ctx = context.WithValue(ctx, AuthTimeContextKey, claims.AuthTime.Time)
+			} else if claims.IssuedAt != nil {
+				ctx = context.WithValue(ctx, AuthTimeContextKey, claims.IssuedAt.Time)
+			}
+
+			// Validade do token e sessão, usadas pela política de sessão do tenant
+			// Sem sid, a sessão é identificada pelo usuário e pelo momento da autenticação
+			if claims.IssuedAt != nil {
+				ctx = context.WithValue(ctx, TokenIssuedAtContextKey, claims.IssuedAt.Time)
+			}
+			if claims.ExpiresAt != nil {
+				ctx = context.WithValue(ctx, TokenExpiresAtContextKey, claims.ExpiresAt.Time)
+			}
+			if claims.SessionID != "" {
+				ctx = context.WithValue(ctx, SessionIDContextKey, claims.SessionID)
+			} else if authTime, ok := ctx.Value(AuthTimeContextKey).(time.Time); ok {
+				ctx = context.WithValue(ctx, SessionIDContextKey, fmt.Sprintf("%s:%d", userID, authTime.Unix()))
+			}
+
+			// Tokens delegados: escopos reduzidos e serviço que age em nome do usuário
+			if claims.Scope != nil {
+				ctx = context.WithValue(ctx, ScopesContextKey, strings.Fields(*claims.Scope))
+			}
+			if claims.Actor != nil {
+				span.SetAttributes(
+					attribute.String("token.actor", claims.Actor.Identifier()),
+					attribute.Int("token.delegation_depth", claims.Actor.Depth()),
+				)
+				ctx = context.WithValue(ctx, ActorContextKey, claims.Actor)
+			}
+			ctx = withRequestContext(ctx, tenantID, claims.Market, requestctx.Actor{
+				UserID:     userID,
+				Username:   claims.Username,
+				Roles:      claims.Roles,
+				Delegation: claims.Actor,
+			})
 
 			// Continuar com o processamento da requisição
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -231,6 +318,16 @@ func AuthMiddleware(logger zerolog.Logger, config AuthConfig) func(http.Handler)
 	}
 }
 
+// withRequestContext injeta o tenant, o mercado e o ator do pedido autenticado, propagados pelos
+// serviços até aos repositórios
+func withRequestContext(ctx context.Context, tenantID uuid.UUID, market string, actor requestctx.Actor) context.Context {
+	ctx = requestctx.WithTenant(ctx, tenantID)
+	if market != "" {
+		ctx = requestctx.WithMarket(ctx, market)
+	}
+	return requestctx.WithActor(ctx, actor)
+}
+
 // extractToken extrai o token JWT da requisição
 func extractToken(r *http.Request, config AuthConfig) (string, error) {
 	// Verificar no header
@@ -313,23 +410,30 @@ func handleAuthError(w http.ResponseWriter, status int, code, message string, lo
 // Helper functions to get values from context
 
 // GetTenantID retorna o ID do tenant do contexto
+// O tenant é guardado como UUID pelo token e como texto pelos headers de desenvolvimento
 func GetTenantID(ctx context.Context) (uuid.UUID, error) {
-	tenantID, ok := ctx.Value(TenantIDContextKey).(string)
+	if tenantID, ok := requestctx.TenantFromContext(ctx); ok {
+		return tenantID, nil
+	}
+	tenantID, ok := contextUUID(ctx, TenantIDContextKey)
 	if !ok {
 		return uuid.Nil, fmt.Errorf("tenant_id não encontrado no contexto")
 	}
 	
-	return uuid.Parse(tenantID)
+	return tenantID, nil
 }
 
 // GetUserID retorna o ID do usuário do contexto
 func GetUserID(ctx context.Context) (uuid.UUID, error) {
-	userID, ok := ctx.Value(UserIDContextKey).(string)
+	if actor, ok := requestctx.ActorFromContext(ctx); ok {
+		return actor.UserID, nil
+	}
+	userID, ok := contextUUID(ctx, UserIDContextKey)
 	if !ok {
 		return uuid.Nil, fmt.Errorf("user_id não encontrado no contexto")
 	}
 	
-	return uuid.Parse(userID)
+	return userID, nil
 }
 
 // GetUsername retorna o nome do usuário do contexto
@@ -350,4 +454,16 @@ func GetRoles(ctx context.Context) ([]string, error) {
 	}
 	
 	return roles, nil
-}
\ No newline at end of file
+}
+
+// GetScopes retorna os escopos do token do contexto; ok é falso quando o token não delimita escopos
+func GetScopes(ctx context.Context) (scopes []string, ok bool) {
+	scopes, ok = ctx.Value(ScopesContextKey).([]string)
+	return scopes, ok
+}
+
+// GetActor retorna o serviço que age em nome do usuário, nos pedidos com token delegado
+func GetActor(ctx context.Context) (*model.ActorClaim, bool) {
+	actor, ok := ctx.Value(ActorContextKey).(*model.ActorClaim)
+	return actor, ok && actor != nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,8 +18,27 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
 )
 
+// DecisionRecorder regista as decisões de autorização para auditoria
+// O registo não pode bloquear o pedido; a amostragem e a gravação ficam a cargo do implementador
+type DecisionRecorder interface {
+	Record(ctx context.Context, decision *model.PermissionDecision)
+}
+
+// UserAttributeResolver resolve os atributos personalizados do usuário para as políticas ABAC
+type UserAttributeResolver interface {
+	ResolveAttributes(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error)
+}
+
+// PermissionBoundaryEnforcer aplica os limites de permissões às decisões do OPA
+type PermissionBoundaryEnforcer interface {
+	ResolvePolicyBoundaries(ctx context.Context, tenantID, userID uuid.UUID, roles []string) (*model.PermissionBoundaryPolicyInput, error)
+	RecordViolation(ctx context.Context, violation *model.PermissionBoundaryViolation) (*model.SecurityIncident, error)
+}
+
 // AuthzConfig representa a configuração do middleware de autorização
 type AuthzConfig struct {
 	OPAEndpoint        string
@@ -27,6 +47,16 @@ type AuthzConfig struct {
 	Timeout            time.Duration
 	DisableAuthorization bool
 	SkipPaths          []string
+	// Auditoria das decisões tomadas pelo OPA; nula desativa o registo
+	DecisionRecorder DecisionRecorder
+	// Atributos personalizados do usuário, expostos às políticas em input.user.attributes;
+	// nulo omite os atributos
+	AttributeResolver UserAttributeResolver
+	// Limites de permissões, expostos às políticas em input.user.permission_boundaries; as recusas
+	// marcadas pela política em boundary_violation são registadas como incidentes. Nulo omite os limites
+	BoundaryEnforcer PermissionBoundaryEnforcer
+	// Blocos CIDR dos proxies de confiança, cujo X-Forwarded-For indica o cliente das decisões registadas
+	TrustedProxies []string
 }
 
 // DefaultAuthzConfig retorna uma configuração padrão para autorização
@@ -45,6 +75,7 @@ func DefaultAuthzConfig() AuthzConfig {
 // utilizando Open Policy Agent (OPA), seguindo as melhores práticas de TOGAF, COBIT e ISO 27001
 func AuthorizationMiddleware(logger zerolog.Logger, config AuthzConfig) func(http.Handler) http.Handler {
 	tracer := otel.GetTracerProvider().Tracer("innovabiz.iam.middleware")
+	trustedProxies := ParseTrustedProxies(logger, config.TrustedProxies)
 	
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,11 +127,44 @@ func AuthorizationMiddleware(logger zerolog.Logger, config AuthzConfig) func(htt
 			vars := mux.Vars(r)
 			
 			// Construir input para o OPA
+			user := map[string]interface{}{
+				"id":     userID.String(),
+				"roles":  roles,
+			}
+			
+			// Adicionar os atributos personalizados do usuário; sem eles as condições ABAC não podem ser avaliadas
+			if config.AttributeResolver != nil {
+				attributes, err := config.AttributeResolver.ResolveAttributes(ctx, tenantID, userID)
+				if err != nil && !errors.Is(err, model.ErrUserNotFound) {
+					span.SetStatus(codes.Error, "Erro ao resolver atributos do usuário")
+					span.RecordError(err)
+					logger.Error().Err(err).Msg("Falha ao resolver atributos do usuário")
+					handleAuthError(w, http.StatusInternalServerError, "authz_error", "Erro interno de autorização", logger)
+					return
+				}
+				if attributes == nil {
+					attributes = map[string]interface{}{}
+				}
+				user["attributes"] = attributes
+			}
+			
+			// Adicionar os limites de permissões; sem eles uma função poderia exercer permissões fora do seu limite
+			if config.BoundaryEnforcer != nil {
+				boundaries, err := config.BoundaryEnforcer.ResolvePolicyBoundaries(ctx, tenantID, userID, roles)
+				if err != nil {
+					span.SetStatus(codes.Error, "Erro ao resolver limites de permissões")
+					span.RecordError(err)
+					logger.Error().Err(err).Msg("Falha ao resolver limites de permissões")
+					handleAuthError(w, http.StatusInternalServerError, "authz_error", "Erro interno de autorização", logger)
+					return
+				}
+				if boundaries != nil {
+					user["permission_boundaries"] = boundaries
+				}
+			}
+			
 			input := map[string]interface{}{
-				"user": map[string]interface{}{
-					"id":     userID.String(),
-					"roles":  roles,
-				},
+				"user": user,
 				"tenant": map[string]interface{}{
 					"id": tenantID.String(),
 				},
@@ -281,6 +345,18 @@ func AuthorizationMiddleware(logger zerolog.Logger, config AuthzConfig) func(htt
 			
 			// Registrar resultado da autorização
 			span.SetAttributes(attribute.Bool("authorization.allowed", allowed))
+			if config.DecisionRecorder != nil {
+				config.DecisionRecorder.Record(ctx, &model.PermissionDecision{
+					TenantID:   tenantID,
+					UserID:     userID,
+					Action:     r.Method,
+					Resource:   r.URL.Path,
+					Allowed:    allowed,
+					PolicyPath: config.PolicyPath,
+					RequestID:  r.Header.Get("X-Request-ID"),
+					ClientIP:   ClientIP(r, trustedProxies),
+				})
+			}
 			logger.Debug().
 				Bool("allowed", allowed).
 				Str("method", r.Method).
@@ -290,6 +366,9 @@ func AuthorizationMiddleware(logger zerolog.Logger, config AuthzConfig) func(htt
 				Msg("Resultado da autorização")
 			
 			if !allowed {
+				if config.BoundaryEnforcer != nil {
+					recordBoundaryViolation(ctx, config.BoundaryEnforcer, result, tenantID, userID, r, logger)
+				}
 				span.SetStatus(codes.Unauthenticated, "Acesso negado")
 				handleAuthError(w, http.StatusForbidden, "access_denied", "Acesso negado. Você não tem permissão para realizar esta operação.", logger)
 				return
@@ -301,6 +380,39 @@ func AuthorizationMiddleware(logger zerolog.Logger, config AuthzConfig) func(htt
 	}
 }
 
+// recordBoundaryViolation regista como incidente a recusa que a política marcou em boundary_violation
+// A falha do registo não altera a resposta, que é sempre a recusa
+func recordBoundaryViolation(ctx context.Context, enforcer PermissionBoundaryEnforcer, result interface{}, tenantID, userID uuid.UUID, r *http.Request, logger zerolog.Logger) {
+	values, ok := result.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if violation, _ := values["boundary_violation"].(bool); !violation {
+		return
+	}
+
+	permissions := []string{}
+	if permission, ok := values["required_permission"].(string); ok {
+		permissions = append(permissions, permission)
+	}
+
+	actorID := userID
+	_, err := enforcer.RecordViolation(ctx, &model.PermissionBoundaryViolation{
+		TenantID:    tenantID,
+		ActorID:     &actorID,
+		Operation:   model.PermissionBoundaryOperationAuthorization,
+		Permissions: permissions,
+		Resource:    r.Method + " " + r.URL.Path,
+		OccurredAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		logger.Error().Err(err).
+			Str("tenant_id", tenantID.String()).
+			Str("user_id", userID.String()).
+			Msg("Falha ao registar violação de limite de permissões")
+	}
+}
+
 // parseQueryParams extrai os parâmetros de consulta da requisição
 func parseQueryParams(r *http.Request) map[string]interface{} {
 	queryParams := make(map[string]interface{})
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Métricas das contas de emergência
+var emergencyAccessRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "iam",
+	Subsystem: "emergency_access",
+	Name:      "requests_total",
+	Help:      "Pedidos das contas de emergência, gravados durante as ativações ou recusados",
+}, []string{"tenant_id", "outcome"})
+
+// emergencyDenyMessages associa cada motivo de recusa ao código e à mensagem da resposta
+var emergencyDenyMessages = map[string][2]string{
+	model.EmergencyDenyReasonSealed:          {"emergency_account_sealed", "Conta de emergência sem ativação aprovada em vigor"},
+	model.EmergencyDenyReasonRecordingFailed: {"emergency_recording_failed", "Não foi possível gravar a ação da conta de emergência"},
+}
+
+// EmergencyAccessAuthorizer verifica e grava os pedidos das contas de emergência
+type EmergencyAccessAuthorizer interface {
+	Authorize(ctx context.Context, req *model.EmergencyAccessRequest) (*model.EmergencyAccessDecision, error)
+}
+
+// EmergencyAccessConfig representa a configuração do middleware das contas de emergência
+// Não há modo fail-open: uma conta de emergência nunca é usada sem o registo das suas ações
+type EmergencyAccessConfig struct {
+	Authorizer EmergencyAccessAuthorizer
+	// Caminhos não verificados
+	SkipPaths []string
+	// Blocos dos proxies de confiança: só os pedidos vindos deles podem indicar o cliente em X-Forwarded-For
+	TrustedProxies []string
+}
+
+// DefaultEmergencyAccessConfig retorna a configuração padrão do middleware das contas de emergência
+func DefaultEmergencyAccessConfig() EmergencyAccessConfig {
+	return EmergencyAccessConfig{
+		SkipPaths: []string{"/health", "/ready", "/docs/"},
+	}
+}
+
+// EmergencyAccessMiddleware recusa com 403 os pedidos das contas de emergência sem ativação em vigor
+// e grava no registo encadeado da ativação cada pedido permitido, antes de o servir. Deve ser
+// registado após o AuthMiddleware; os pedidos sem usuário autenticado não são verificados
+func EmergencyAccessMiddleware(logger zerolog.Logger, config EmergencyAccessConfig) func(http.Handler) http.Handler {
+	tracer := otel.GetTracerProvider().Tracer("innovabiz.iam.middleware")
+	trustedProxies := ParseTrustedProxies(logger, config.TrustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Authorizer == nil || hasPathPrefix(r.URL.Path, config.SkipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenantID, ok := contextUUID(r.Context(), TenantIDContextKey)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, ok := contextUUID(r.Context(), UserIDContextKey)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := tracer.Start(r.Context(), "emergencyaccess.middleware")
+			defer span.End()
+
+			access := &model.EmergencyAccessRequest{
+				TenantID:  tenantID,
+				UserID:    userID,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Query:     r.URL.RawQuery,
+				IPAddress: ClientIP(r, trustedProxies),
+				UserAgent: r.UserAgent(),
+				RequestID: r.Header.Get("X-Request-ID"),
+			}
+			span.SetAttributes(attribute.String("tenant.id", tenantID.String()))
+
+			decision, err := config.Authorizer.Authorize(ctx, access)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "Contas de emergência indisponíveis")
+				logger.Error().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("path", r.URL.Path).
+					Msg("Erro ao verificar contas de emergência")
+				handleAuthError(w, http.StatusServiceUnavailable, "emergency_access_unavailable", "Não foi possível verificar o acesso", logger)
+				return
+			}
+
+			span.SetAttributes(
+				attribute.Bool("emergencyaccess.account", decision.EmergencyAccount),
+				attribute.Bool("emergencyaccess.allowed", decision.Allowed),
+				attribute.String("emergencyaccess.reason", decision.Reason),
+			)
+
+			if !decision.EmergencyAccount {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if !decision.Allowed {
+				emergencyAccessRequestsTotal.WithLabelValues(tenantID.String(), decision.Reason).Inc()
+				span.SetStatus(codes.Error, "Pedido da conta de emergência recusado")
+				logger.Warn().
+					Str("tenant_id", tenantID.String()).
+					Str("user_id", userID.String()).
+					Str("client_ip", access.IPAddress).
+					Str("path", r.URL.Path).
+					Str("reason", decision.Reason).
+					Msg("Pedido da conta de emergência recusado")
+				message, ok := emergencyDenyMessages[decision.Reason]
+				if !ok {
+					message = emergencyDenyMessages[model.EmergencyDenyReasonSealed]
+				}
+				handleAuthError(w, http.StatusForbidden, message[0], message[1], logger)
+				return
+			}
+
+			emergencyAccessRequestsTotal.WithLabelValues(tenantID.String(), "recorded").Inc()
+			if decision.ActivationID != nil {
+				span.SetAttributes(attribute.String("emergencyaccess.activation_id", decision.ActivationID.String()))
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Métricas das políticas de rede
+var (
+	networkPolicyBlockedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "iam",
+		Subsystem: "network_policy",
+		Name:      "blocked_requests_total",
+		Help:      "Pedidos recusados pelas políticas de rede do tenant",
+	}, []string{"tenant_id", "scope", "reason"})
+
+	networkPolicyBreakGlassTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "iam",
+		Subsystem: "network_policy",
+		Name:      "break_glass_requests_total",
+		Help:      "Pedidos bloqueados pelas políticas de rede permitidos por acesso de emergência",
+	}, []string{"tenant_id", "scope"})
+)
+
+// NetworkPolicyEvaluator decide se um pedido é permitido pelas políticas de rede do tenant
+type NetworkPolicyEvaluator interface {
+	Evaluate(ctx context.Context, req *model.NetworkAccessRequest) (*model.NetworkDecision, error)
+}
+
+// NetworkPolicyConfig representa a configuração do middleware de políticas de rede
+type NetworkPolicyConfig struct {
+	Evaluator NetworkPolicyEvaluator
+	// Prefixos dos caminhos da API de administração, sujeitos às políticas de âmbito admin_api
+	AdminPathPrefixes []string
+	// Caminhos não avaliados, como o início de sessão, em que o usuário ainda não está identificado
+	SkipPaths []string
+	// Blocos dos proxies de confiança: só os pedidos vindos deles podem indicar o cliente em X-Forwarded-For
+	TrustedProxies []string
+	// FailOpen permite os pedidos quando as políticas não podem ser avaliadas; por omissão são recusados
+	FailOpen bool
+}
+
+// DefaultNetworkPolicyConfig retorna a configuração padrão, em que toda a API é de administração
+// exceto o início de sessão sem senha, a autenticação das contas de serviço e a resposta
+// "não fui eu" das notificações de login, feita a partir do link enviado ao usuário
+func DefaultNetworkPolicyConfig() NetworkPolicyConfig {
+	return NetworkPolicyConfig{
+		AdminPathPrefixes: []string{"/api/v1/"},
+		SkipPaths: []string{
+			"/api/v1/passwordless/start",
+			"/api/v1/passwordless/magic-link/verify",
+			"/api/v1/passwordless/otp/verify",
+			"/api/v1/service-accounts/token",
+			"/api/v1/login-notifications/respond",
+		},
+	}
+}
+
+// NetworkPolicyMiddleware recusa com 403 os pedidos de endereços bloqueados pelas políticas de
+// rede do tenant, para a API de administração e para as funções do usuário. As decisões ficam em
+// cache no avaliador; os pedidos permitidos por acesso de emergência são auditados por ele.
+// Deve ser registado após o AuthMiddleware, para que o tenant, o usuário e as funções da sessão
+// estejam no contexto; sem sessão, o tenant é lido do cabeçalho X-Tenant-ID
+func NetworkPolicyMiddleware(logger zerolog.Logger, config NetworkPolicyConfig) func(http.Handler) http.Handler {
+	tracer := otel.GetTracerProvider().Tracer("innovabiz.iam.middleware")
+
+	trustedProxies := ParseTrustedProxies(logger, config.TrustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Evaluator == nil || hasPathPrefix(r.URL.Path, config.SkipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenantID, ok := requestTenantID(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := tracer.Start(r.Context(), "networkpolicy.middleware")
+			defer span.End()
+
+			// O acesso de emergência só se aplica ao usuário da sessão, nunca a um cabeçalho
+			userID, _ := contextUUID(ctx, UserIDContextKey)
+			roles, _ := ctx.Value(RolesContextKey).([]string)
+			access := &model.NetworkAccessRequest{
+				TenantID:  tenantID,
+				UserID:    userID,
+				Roles:     roles,
+				IPAddress: ClientIP(r, trustedProxies),
+				AdminAPI:  hasPathPrefix(r.URL.Path, config.AdminPathPrefixes),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+			}
+			span.SetAttributes(
+				attribute.String("tenant.id", tenantID.String()),
+				attribute.String("networkpolicy.client_ip", access.IPAddress),
+				attribute.Bool("networkpolicy.admin_api", access.AdminAPI),
+			)
+
+			decision, err := config.Evaluator.Evaluate(ctx, access)
+			if err != nil {
+				span.RecordError(err)
+				logger.Error().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("path", r.URL.Path).
+					Bool("fail_open", config.FailOpen).
+					Msg("Erro ao avaliar políticas de rede")
+				if config.FailOpen {
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+				span.SetStatus(codes.Error, "Políticas de rede indisponíveis")
+				handleAuthError(w, http.StatusServiceUnavailable, "network_policy_unavailable", "Não foi possível verificar o acesso de rede", logger)
+				return
+			}
+
+			span.SetAttributes(
+				attribute.Bool("networkpolicy.allowed", decision.Allowed),
+				attribute.String("networkpolicy.reason", decision.Reason),
+				attribute.Bool("networkpolicy.break_glass", decision.BreakGlassID != nil),
+			)
+
+			if !decision.Allowed {
+				networkPolicyBlockedTotal.WithLabelValues(tenantID.String(), string(decision.Scope), decision.Reason).Inc()
+				span.SetStatus(codes.Error, "Endereço bloqueado pela política de rede")
+				logger.Warn().
+					Str("tenant_id", tenantID.String()).
+					Str("user_id", userID.String()).
+					Str("client_ip", access.IPAddress).
+					Str("path", r.URL.Path).
+					Str("scope", string(decision.Scope)).
+					Str("role_code", decision.RoleCode).
+					Str("reason", decision.Reason).
+					Msg("Pedido recusado pela política de rede do tenant")
+				handleAuthError(w, http.StatusForbidden, "network_access_denied", "Acesso não permitido a partir deste endereço", logger)
+				return
+			}
+
+			if decision.BreakGlassID != nil {
+				networkPolicyBreakGlassTotal.WithLabelValues(tenantID.String(), string(decision.Scope)).Inc()
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestTenantID retorna o tenant da sessão ou, sem sessão, do cabeçalho X-Tenant-ID
+func requestTenantID(r *http.Request) (uuid.UUID, bool) {
+	if tenantID, ok := contextUUID(r.Context(), TenantIDContextKey); ok {
+		return tenantID, true
+	}
+	tenantID, err := uuid.Parse(r.Header.Get("X-Tenant-ID"))
+	return tenantID, err == nil && tenantID != uuid.Nil
+}
+
+// contextUUID lê um identificador do contexto, gravado como uuid.UUID pelo AuthMiddleware
+// ou como texto no modo de desenvolvimento
+func contextUUID(ctx context.Context, key contextKey) (uuid.UUID, bool) {
+	switch value := ctx.Value(key).(type) {
+	case uuid.UUID:
+		return value, value != uuid.Nil
+	case string:
+		id, err := uuid.Parse(value)
+		return id, err == nil && id != uuid.Nil
+	}
+	return uuid.Nil, false
+}
+
+// ParseTrustedProxies interpreta os blocos dos proxies de confiança, ignorando os inválidos
+func ParseTrustedProxies(logger zerolog.Logger, cidrs []string) []*net.IPNet {
+	var trustedProxies []*net.IPNet
+	for _, cidr := range cidrs {
+		network, err := model.ParseNetworkCIDR(cidr)
+		if err != nil {
+			logger.Error().Err(err).Str("cidr", cidr).Msg("Proxy de confiança inválido ignorado")
+			continue
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return trustedProxies
+}
+
+// ClientIP retorna o endereço do cliente. X-Forwarded-For só é considerado quando o
+// pedido vem de um proxy de confiança; é percorrido da direita para a esquerda até ao primeiro
+// salto que não é um proxy de confiança, para que o cliente não possa indicar outro endereço
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if len(trustedProxies) == 0 || !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedProxy(hop, trustedProxies) {
+			return hop
+		}
+		remote = hop
+	}
+	return remote
+}
+
+// isTrustedProxy indica se o endereço pertence a um proxy de confiança
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix indica se o caminho começa por algum dos prefixos
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+)
+
+// Métricas das políticas de sessão
+var sessionPolicyRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "iam",
+	Subsystem: "session_policy",
+	Name:      "rejected_requests_total",
+	Help:      "Pedidos recusados pela política de sessão do tenant",
+}, []string{"tenant_id", "reason"})
+
+// sessionRejectMessages associa cada motivo de recusa ao código e à mensagem da resposta
+var sessionRejectMessages = map[string][2]string{
+	model.SessionRejectTokenLifetime: {"token_expired", "Token de autenticação expirado pela política de sessão do tenant"},
+	model.SessionRejectAbsolute:      {"session_expired", "Sessão expirada; autentique-se novamente"},
+	model.SessionRejectIdle:          {"session_idle_timeout", "Sessão terminada por inatividade; autentique-se novamente"},
+}
+
+// SessionPolicyEnforcer verifica um pedido autenticado contra a política de sessão do tenant
+type SessionPolicyEnforcer interface {
+	CheckSession(ctx context.Context, check *model.SessionCheck) (*model.SessionDecision, error)
+}
+
+// SessionPolicyConfig representa a configuração do middleware de políticas de sessão
+type SessionPolicyConfig struct {
+	Enforcer SessionPolicyEnforcer
+	// Caminhos não verificados
+	SkipPaths []string
+	// FailOpen permite os pedidos quando a política não pode ser verificada; por omissão são recusados
+	FailOpen bool
+}
+
+// DefaultSessionPolicyConfig retorna a configuração padrão do middleware de políticas de sessão
+func DefaultSessionPolicyConfig() SessionPolicyConfig {
+	return SessionPolicyConfig{
+		SkipPaths: []string{"/health", "/ready", "/docs/"},
+	}
+}
+
+// SessionPolicyMiddleware recusa com 401 os pedidos cujo token ou sessão excedem a política de
+// sessão do tenant: tokens emitidos com validade superior à permitida, sessões autenticadas há
+// mais tempo que a duração máxima e sessões inativas. Deve ser registado após o AuthMiddleware;
+// os pedidos sem sessão autenticada não são verificados
+func SessionPolicyMiddleware(logger zerolog.Logger, config SessionPolicyConfig) func(http.Handler) http.Handler {
+	tracer := otel.GetTracerProvider().Tracer("innovabiz.iam.middleware")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Enforcer == nil || hasPathPrefix(r.URL.Path, config.SkipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenantID, ok := contextUUID(r.Context(), TenantIDContextKey)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := tracer.Start(r.Context(), "sessionpolicy.middleware")
+			defer span.End()
+
+			userID, _ := contextUUID(ctx, UserIDContextKey)
+			check := &model.SessionCheck{
+				TenantID:  tenantID,
+				UserID:    userID,
+				SessionID: contextString(ctx, SessionIDContextKey),
+				Market:    contextString(ctx, MarketContextKey),
+				IssuedAt:  contextTime(ctx, TokenIssuedAtContextKey),
+				ExpiresAt: contextTime(ctx, TokenExpiresAtContextKey),
+				AuthTime:  contextTime(ctx, AuthTimeContextKey),
+			}
+			span.SetAttributes(
+				attribute.String("tenant.id", tenantID.String()),
+				attribute.Bool("sessionpolicy.tracked", check.SessionID != ""),
+			)
+
+			decision, err := config.Enforcer.CheckSession(ctx, check)
+			if err != nil {
+				span.RecordError(err)
+				logger.Error().Err(err).
+					Str("tenant_id", tenantID.String()).
+					Str("path", r.URL.Path).
+					Bool("fail_open", config.FailOpen).
+					Msg("Erro ao verificar política de sessão")
+				if config.FailOpen {
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+				span.SetStatus(codes.Error, "Política de sessão indisponível")
+				handleAuthError(w, http.StatusServiceUnavailable, "session_policy_unavailable", "Não foi possível verificar a sessão", logger)
+				return
+			}
+
+			span.SetAttributes(
+				attribute.Bool("sessionpolicy.allowed", decision.Allowed),
+				attribute.String("sessionpolicy.reason", decision.Reason),
+			)
+
+			if !decision.Allowed {
+				sessionPolicyRejectedTotal.WithLabelValues(tenantID.String(), decision.Reason).Inc()
+				span.SetStatus(codes.Error, "Sessão recusada pela política do tenant")
+				logger.Info().
+					Str("tenant_id", tenantID.String()).
+					Str("user_id", userID.String()).
+					Str("path", r.URL.Path).
+					Str("reason", decision.Reason).
+					Msg("Pedido recusado pela política de sessão do tenant")
+				message, ok := sessionRejectMessages[decision.Reason]
+				if !ok {
+					message = sessionRejectMessages[model.SessionRejectAbsolute]
+				}
+				handleAuthError(w, http.StatusUnauthorized, message[0], message[1], logger)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// contextString lê um texto do contexto, vazio se não existir
+func contextString(ctx context.Context, key contextKey) string {
+	value, _ := ctx.Value(key).(string)
+	return value
+}
+
+// contextTime lê um instante do contexto, zero se não existir
+func contextTime(ctx context.Context, key contextKey) time.Time {
+	value, _ := ctx.Value(key).(time.Time)
+	return value
+}
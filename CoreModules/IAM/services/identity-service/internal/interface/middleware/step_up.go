@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Níveis de MFA da sessão, do mais fraco ao mais forte
+const (
+	MFALevelNone     = "none"
+	MFALevelBasic    = "basic"
+	MFALevelMedium   = "medium"
+	MFALevelHigh     = "high"
+	MFALevelAdvanced = "advanced"
+)
+
+// Motivos do desafio de step-up
+const (
+	StepUpReasonMFALevel       = "mfa_level_insufficient"
+	StepUpReasonAuthExpired    = "authentication_too_old"
+	StepUpReasonMissingContext = "authentication_context_missing"
+)
+
+// mfaLevelRank ordena os níveis de MFA para comparação
+var mfaLevelRank = map[string]int{
+	MFALevelNone:     0,
+	MFALevelBasic:    1,
+	MFALevelMedium:   2,
+	MFALevelHigh:     3,
+	MFALevelAdvanced: 4,
+}
+
+// MFALevelSatisfies verifica se o nível de MFA da sessão atende ao nível exigido
+func MFALevelSatisfies(current, required string) bool {
+	requiredRank, ok := mfaLevelRank[strings.ToLower(required)]
+	if !ok {
+		return false
+	}
+	currentRank, ok := mfaLevelRank[strings.ToLower(current)]
+	return ok && currentRank >= requiredRank
+}
+
+// StepUpPolicy define a autenticação exigida por um mercado para operações sensíveis
+type StepUpPolicy struct {
+	RequiredMFALevel string
+	MaxAuthAge       time.Duration // Janela de autenticação recente
+}
+
+// StepUpConfig representa a configuração do middleware de step-up
+type StepUpConfig struct {
+	// Políticas por mercado (em minúsculas)
+	Policies map[string]StepUpPolicy
+	// Mercado usado quando a sessão não indica mercado ou o mercado não tem política
+	DefaultMarket string
+	// Rotas sensíveis no formato "MÉTODO modelo-de-caminho", ex.: "DELETE /api/v1/roles/{id}"
+	SensitiveRoutes []string
+}
+
+// DefaultStepUpConfig retorna a configuração padrão, alinhada com o RequiredMFALevel dos mercados
+func DefaultStepUpConfig() StepUpConfig {
+	return StepUpConfig{
+		Policies: map[string]StepUpPolicy{
+			"angola":     {RequiredMFALevel: MFALevelHigh, MaxAuthAge: 5 * time.Minute},
+			"brazil":     {RequiredMFALevel: MFALevelMedium, MaxAuthAge: 15 * time.Minute},
+			"eu":         {RequiredMFALevel: MFALevelHigh, MaxAuthAge: 5 * time.Minute},
+			"usa":        {RequiredMFALevel: MFALevelMedium, MaxAuthAge: 15 * time.Minute},
+			"mozambique": {RequiredMFALevel: MFALevelHigh, MaxAuthAge: 5 * time.Minute},
+			"global":     {RequiredMFALevel: MFALevelMedium, MaxAuthAge: 15 * time.Minute},
+		},
+		DefaultMarket: "global",
+		SensitiveRoutes: []string{
+			http.MethodDelete + " /api/v1/roles/{id}",
+			http.MethodPost + " /api/v1/system-roles/sync",
+		},
+	}
+}
+
+// policyFor retorna a política do mercado, recorrendo ao mercado padrão
+func (c StepUpConfig) policyFor(market string) (string, StepUpPolicy, bool) {
+	market = strings.ToLower(market)
+	if policy, ok := c.Policies[market]; ok {
+		return market, policy, true
+	}
+	policy, ok := c.Policies[strings.ToLower(c.DefaultMarket)]
+	return strings.ToLower(c.DefaultMarket), policy, ok
+}
+
+// StepUpChallenge descreve a autenticação adicional que o cliente deve realizar
+type StepUpChallenge struct {
+	Reason            string `json:"reason"`
+	Market            string `json:"market"`
+	RequiredMFALevel  string `json:"required_mfa_level"`
+	CurrentMFALevel   string `json:"current_mfa_level,omitempty"`
+	MaxAuthAgeSeconds int64  `json:"max_auth_age_seconds"`
+	AuthAgeSeconds    int64  `json:"auth_age_seconds,omitempty"`
+}
+
+// StepUpChallengeResponse é a resposta 401 devolvida quando a sessão exige step-up
+type StepUpChallengeResponse struct {
+	Status    int             `json:"status"`
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Challenge StepUpChallenge `json:"challenge"`
+}
+
+// StepUpMiddleware exige, nas rotas sensíveis, que a sessão atenda ao nível de MFA
+// e à janela de autenticação recente do mercado. Deve ser registado após o AuthMiddleware,
+// com router.Use, para que a rota correspondente já esteja resolvida
+func StepUpMiddleware(logger zerolog.Logger, config StepUpConfig) func(http.Handler) http.Handler {
+	tracer := otel.GetTracerProvider().Tracer("innovabiz.iam.middleware")
+
+	sensitive := make(map[string]bool, len(config.SensitiveRoutes))
+	for _, route := range config.SensitiveRoutes {
+		sensitive[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			template, err := route.GetPathTemplate()
+			if err != nil || !sensitive[r.Method+" "+template] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := tracer.Start(r.Context(), "stepup.middleware")
+			defer span.End()
+
+			market, _ := ctx.Value(MarketContextKey).(string)
+			market, policy, ok := config.policyFor(market)
+			if !ok {
+				span.SetStatus(codes.Error, "Política de step-up não configurada")
+				logger.Error().Str("market", market).Msg("Política de step-up não configurada para o mercado")
+				handleAuthError(w, http.StatusInternalServerError, "authz_error", "Erro interno de autorização", logger)
+				return
+			}
+
+			mfaLevel, _ := ctx.Value(MFALevelContextKey).(string)
+			authTime, _ := ctx.Value(AuthTimeContextKey).(time.Time)
+
+			challenge := StepUpChallenge{
+				Market:            market,
+				RequiredMFALevel:  policy.RequiredMFALevel,
+				CurrentMFALevel:   mfaLevel,
+				MaxAuthAgeSeconds: int64(policy.MaxAuthAge / time.Second),
+			}
+			switch {
+			case mfaLevel == "" || authTime.IsZero():
+				challenge.Reason = StepUpReasonMissingContext
+			case !MFALevelSatisfies(mfaLevel, policy.RequiredMFALevel):
+				challenge.Reason = StepUpReasonMFALevel
+			default:
+				if age := time.Since(authTime); age > policy.MaxAuthAge {
+					challenge.Reason = StepUpReasonAuthExpired
+					challenge.AuthAgeSeconds = int64(age / time.Second)
+				}
+			}
+
+			span.SetAttributes(
+				attribute.String("stepup.route", r.Method+" "+template),
+				attribute.String("stepup.market", market),
+				attribute.String("stepup.required_mfa_level", policy.RequiredMFALevel),
+				attribute.String("stepup.current_mfa_level", mfaLevel),
+				attribute.Bool("stepup.required", challenge.Reason != ""),
+			)
+
+			if challenge.Reason != "" {
+				span.SetStatus(codes.Error, "Step-up de autenticação necessário")
+				logger.Info().
+					Str("path", r.URL.Path).
+					Str("market", market).
+					Str("reason", challenge.Reason).
+					Str("required_mfa_level", policy.RequiredMFALevel).
+					Str("current_mfa_level", mfaLevel).
+					Msg("Operação sensível exige step-up de autenticação")
+				writeStepUpChallenge(w, challenge)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// writeStepUpChallenge responde 401 com o desafio estruturado e o cabeçalho
+// WWW-Authenticate do OAuth 2.0 Step-Up Authentication Challenge (RFC 9470)
+func writeStepUpChallenge(w http.ResponseWriter, challenge StepUpChallenge) {
+	message := "Esta operação exige autenticação multifator recente"
+	switch challenge.Reason {
+	case StepUpReasonMFALevel:
+		message = fmt.Sprintf("Esta operação exige autenticação multifator de nível %s", challenge.RequiredMFALevel)
+	case StepUpReasonAuthExpired:
+		message = fmt.Sprintf("Esta operação exige autenticação realizada nos últimos %d segundos", challenge.MaxAuthAgeSeconds)
+	}
+
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+		`Bearer error="insufficient_user_authentication", error_description="%s", acr_values="%s", max_age=%d`,
+		challenge.Reason, challenge.RequiredMFALevel, challenge.MaxAuthAgeSeconds))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+
+	json.NewEncoder(w).Encode(StepUpChallengeResponse{
+		Status:    http.StatusUnauthorized,
+		Code:      "step_up_required",
+		Message:   message,
+		Challenge: challenge,
+	})
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/domain/requestctx"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+const testJWTSecret = "segredo-de-teste"
+
+// serveAuth executa o pedido pelo middleware de autenticação e retorna o contexto recebido pelo handler
+func serveAuth(config middleware.AuthConfig, req *http.Request) (*httptest.ResponseRecorder, context.Context) {
+	var received context.Context
+	handler := middleware.AuthMiddleware(zerolog.Nop(), config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Context()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, received
+}
+
+func signedToken(t *testing.T, claims middleware.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+// TestAuthMiddlewareInjectsRequestContext verifica que o tenant, o mercado e o ator do token chegam ao contexto
+func TestAuthMiddlewareInjectsRequestContext(t *testing.T) {
+	config := middleware.DefaultAuthConfig()
+	config.JWTSecret = testJWTSecret
+	config.DisableAuthentication = false
+
+	tenantID, userID := uuid.New(), uuid.New()
+	delegation := &model.ActorClaim{Subject: "svc-reporting", ClientID: "reporting"}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, middleware.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		TenantID: tenantID.String(),
+		Username: "ana.silva",
+		Roles:    []string{"ANALYST"},
+		Market:   "angola",
+		Actor:    delegation,
+	}))
+
+	rec, ctx := serveAuth(config, req)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	got, ok := requestctx.TenantFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, tenantID, got)
+	assert.Equal(t, "angola", requestctx.MarketFromContext(ctx))
+	actor, ok := requestctx.ActorFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, userID, actor.UserID)
+	assert.Equal(t, "ana.silva", actor.Username)
+	assert.Equal(t, []string{"ANALYST"}, actor.Roles)
+	assert.Equal(t, delegation, actor.Delegation)
+
+	// Os acessores do middleware leem os mesmos valores
+	gotTenant, err := middleware.GetTenantID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, gotTenant)
+	gotUser, err := middleware.GetUserID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, userID, gotUser)
+}
+
+// TestAuthMiddlewareDevelopmentHeaders verifica a injeção a partir dos headers simulados de desenvolvimento
+func TestAuthMiddlewareDevelopmentHeaders(t *testing.T) {
+	config := middleware.DefaultAuthConfig()
+	config.DisableAuthentication = true
+
+	tenantID, userID := uuid.New(), uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.Header.Set("X-Tenant-ID", tenantID.String())
+	req.Header.Set("X-User-ID", userID.String())
+	req.Header.Set("X-Market", "brasil")
+
+	rec, ctx := serveAuth(config, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	got, ok := requestctx.TenantFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, tenantID, got)
+	assert.Equal(t, "brasil", requestctx.MarketFromContext(ctx))
+	actor, ok := requestctx.ActorFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, userID, actor.UserID)
+
+	// Um tenant simulado inválido é rejeitado em vez de chegar sem tenant aos repositórios
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.Header.Set("X-Tenant-ID", "tenant-invalido")
+	rec, _ = serveAuth(config, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// TestAuthMiddlewareSkipPaths verifica que as rotas públicas seguem sem tenant no contexto
+func TestAuthMiddlewareSkipPaths(t *testing.T) {
+	config := middleware.DefaultAuthConfig()
+	config.DisableAuthentication = false
+	config.SkipPaths = append(config.SkipPaths, "/api/v1/passwordless/start")
+
+	rec, ctx := serveAuth(config, httptest.NewRequest(http.MethodPost, "/api/v1/passwordless/start", nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	_, err := requestctx.RequireTenant(ctx)
+	assert.ErrorIs(t, err, requestctx.ErrTenantRequired)
+
+	rec, _ = serveAuth(config, httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// TestGetTenantIDAcceptsStoredTypes verifica os acessores com o tenant guardado como UUID ou como texto
+func TestGetTenantIDAcceptsStoredTypes(t *testing.T) {
+	tenantID := uuid.New()
+
+	for name, value := range map[string]interface{}{"uuid": tenantID, "texto": tenantID.String()} {
+		ctx := context.WithValue(context.Background(), middleware.TenantIDContextKey, value)
+		got, err := middleware.GetTenantID(ctx)
+		require.NoError(t, err, name)
+		assert.Equal(t, tenantID, got, name)
+	}
+
+	_, err := middleware.GetTenantID(context.Background())
+	assert.Error(t, err)
+}
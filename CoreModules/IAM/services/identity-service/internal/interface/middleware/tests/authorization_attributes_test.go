@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// fakeAttributeResolver retorna atributos fixos ou um erro
+type fakeAttributeResolver struct {
+	attributes map[string]interface{}
+	err        error
+}
+
+func (f *fakeAttributeResolver) ResolveAttributes(ctx context.Context, tenantID, userID uuid.UUID) (map[string]interface{}, error) {
+	return f.attributes, f.err
+}
+
+// opaStub simula o PDP: permite quando o usuário tem o nível de credenciação "secret" e guarda o input recebido
+func opaStub(t *testing.T, received *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*received = body.Input
+
+		user := body.Input["user"].(map[string]interface{})
+		attributes, _ := user["attributes"].(map[string]interface{})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"allow": attributes["clearance_level"] == "secret"},
+		})
+	}))
+}
+
+// authzRouter monta um router autorizado pelo PDP simulado, com a sessão autenticada no contexto
+func authzRouter(opaURL string, resolver middleware.UserAttributeResolver) *mux.Router {
+	config := middleware.DefaultAuthzConfig()
+	config.OPAEndpoint = opaURL
+	config.AttributeResolver = resolver
+
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.TenantIDContextKey, uuid.NewString())
+			ctx = context.WithValue(ctx, middleware.UserIDContextKey, uuid.NewString())
+			ctx = context.WithValue(ctx, middleware.RolesContextKey, []string{"analyst"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Use(middleware.AuthorizationMiddleware(zerolog.Nop(), config))
+	router.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodGet)
+	return router
+}
+
+// TestAuthorizationExposesUserAttributesToPolicies verifica que as políticas recebem os atributos do usuário
+func TestAuthorizationExposesUserAttributesToPolicies(t *testing.T) {
+	var input map[string]interface{}
+	opa := opaStub(t, &input)
+	defer opa.Close()
+
+	resolver := &fakeAttributeResolver{attributes: map[string]interface{}{"clearance_level": "secret", "cost_center": "CC-100"}}
+	rec := httptest.NewRecorder()
+	authzRouter(opa.URL, resolver).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	user := input["user"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"clearance_level": "secret", "cost_center": "CC-100"}, user["attributes"])
+
+	resolver.attributes = map[string]interface{}{"clearance_level": "internal"}
+	rec = httptest.NewRecorder()
+	authzRouter(opa.URL, resolver).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// TestAuthorizationAttributesForNonUsers verifica que os chamadores sem usuário no tenant recebem atributos vazios
+func TestAuthorizationAttributesForNonUsers(t *testing.T) {
+	var input map[string]interface{}
+	opa := opaStub(t, &input)
+	defer opa.Close()
+
+	rec := httptest.NewRecorder()
+	authzRouter(opa.URL, &fakeAttributeResolver{err: model.ErrUserNotFound}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	user := input["user"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{}, user["attributes"])
+}
+
+// TestAuthorizationFailsClosedWhenAttributesUnavailable verifica que uma falha ao resolver os atributos não autoriza o pedido
+func TestAuthorizationFailsClosedWhenAttributesUnavailable(t *testing.T) {
+	var input map[string]interface{}
+	opa := opaStub(t, &input)
+	defer opa.Close()
+
+	rec := httptest.NewRecorder()
+	authzRouter(opa.URL, &fakeAttributeResolver{err: errors.New("base de dados indisponível")}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Nil(t, input, "o PDP não deve ser consultado")
+}
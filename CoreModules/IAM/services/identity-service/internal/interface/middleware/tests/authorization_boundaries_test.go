@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// fakeBoundaryEnforcer retorna limites fixos e guarda as violações registadas
+type fakeBoundaryEnforcer struct {
+	mu         sync.Mutex
+	boundaries *model.PermissionBoundaryPolicyInput
+	err        error
+	roles      []string
+	violations []*model.PermissionBoundaryViolation
+}
+
+func (f *fakeBoundaryEnforcer) ResolvePolicyBoundaries(ctx context.Context, tenantID, userID uuid.UUID, roles []string) (*model.PermissionBoundaryPolicyInput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.roles = roles
+	return f.boundaries, f.err
+}
+
+func (f *fakeBoundaryEnforcer) RecordViolation(ctx context.Context, violation *model.PermissionBoundaryViolation) (*model.SecurityIncident, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.violations = append(f.violations, violation)
+	return &model.SecurityIncident{ID: uuid.New()}, nil
+}
+
+// boundaryOPAStub simula o PDP: a função "role_manager" concede "roles:assign_user", mas só dentro
+// do limite da função recebido em input.user.permission_boundaries
+func boundaryOPAStub(t *testing.T, received *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		*received = body.Input
+
+		allow := true
+		user := body.Input["user"].(map[string]interface{})
+		if boundaries, ok := user["permission_boundaries"].(map[string]interface{}); ok {
+			roles, _ := boundaries["roles"].(map[string]interface{})
+			if patterns, bounded := roles["role_manager"].([]interface{}); bounded {
+				allow = false
+				for _, pattern := range patterns {
+					allow = allow || pattern == "roles:assign_user"
+				}
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"allow":               allow,
+				"boundary_violation":  !allow,
+				"required_permission": "roles:assign_user",
+			},
+		})
+	}))
+}
+
+// boundaryRouter monta um router autorizado pelo PDP simulado, com os limites de permissões configurados
+func boundaryRouter(opaURL string, enforcer middleware.PermissionBoundaryEnforcer, tenantID, userID uuid.UUID) *mux.Router {
+	config := middleware.DefaultAuthzConfig()
+	config.OPAEndpoint = opaURL
+	config.BoundaryEnforcer = enforcer
+
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.TenantIDContextKey, tenantID.String())
+			ctx = context.WithValue(ctx, middleware.UserIDContextKey, userID.String())
+			ctx = context.WithValue(ctx, middleware.RolesContextKey, []string{"role_manager"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Use(middleware.AuthorizationMiddleware(zerolog.Nop(), config))
+	router.HandleFunc("/api/v1/roles/{id}/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPost)
+	return router
+}
+
+// TestAuthorizationRecordsBoundaryViolations verifica que as recusas por limites de permissões são registadas
+func TestAuthorizationRecordsBoundaryViolations(t *testing.T) {
+	var input map[string]interface{}
+	opa := boundaryOPAStub(t, &input)
+	defer opa.Close()
+
+	tenantID, userID := uuid.New(), uuid.New()
+	enforcer := &fakeBoundaryEnforcer{boundaries: &model.PermissionBoundaryPolicyInput{
+		Roles: map[string][]string{"role_manager": {"roles:read", "roles:list"}},
+	}}
+
+	rec := httptest.NewRecorder()
+	boundaryRouter(opa.URL, enforcer, tenantID, userID).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/roles/abc/users", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, []string{"role_manager"}, enforcer.roles)
+	user := input["user"].(map[string]interface{})
+	boundaries := user["permission_boundaries"].(map[string]interface{})
+	assert.Nil(t, boundaries["user"], "o usuário sem limite próprio não é limitado")
+	assert.Equal(t, map[string]interface{}{"role_manager": []interface{}{"roles:read", "roles:list"}}, boundaries["roles"])
+
+	require.Len(t, enforcer.violations, 1)
+	violation := enforcer.violations[0]
+	assert.Equal(t, tenantID, violation.TenantID)
+	require.NotNil(t, violation.ActorID)
+	assert.Equal(t, userID, *violation.ActorID)
+	assert.Equal(t, model.PermissionBoundaryOperationAuthorization, violation.Operation)
+	assert.Equal(t, []string{"roles:assign_user"}, violation.Permissions)
+	assert.Equal(t, "POST /api/v1/roles/abc/users", violation.Resource)
+
+	// Dentro do limite o pedido é autorizado e nada é registado
+	enforcer.boundaries.Roles["role_manager"] = []string{"roles:assign_user"}
+	rec = httptest.NewRecorder()
+	boundaryRouter(opa.URL, enforcer, tenantID, userID).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/roles/abc/users", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Len(t, enforcer.violations, 1)
+}
+
+// TestAuthorizationOmitsBoundariesWithoutTenantBoundaries verifica que os tenants sem limites não expõem limites às políticas
+func TestAuthorizationOmitsBoundariesWithoutTenantBoundaries(t *testing.T) {
+	var input map[string]interface{}
+	opa := boundaryOPAStub(t, &input)
+	defer opa.Close()
+
+	rec := httptest.NewRecorder()
+	boundaryRouter(opa.URL, &fakeBoundaryEnforcer{}, uuid.New(), uuid.New()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/roles/abc/users", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	user := input["user"].(map[string]interface{})
+	assert.NotContains(t, user, "permission_boundaries")
+}
+
+// TestAuthorizationFailsClosedWhenBoundariesUnavailable verifica que uma falha ao resolver os limites não autoriza o pedido
+func TestAuthorizationFailsClosedWhenBoundariesUnavailable(t *testing.T) {
+	var input map[string]interface{}
+	opa := boundaryOPAStub(t, &input)
+	defer opa.Close()
+
+	enforcer := &fakeBoundaryEnforcer{err: errors.New("base de dados indisponível")}
+	rec := httptest.NewRecorder()
+	boundaryRouter(opa.URL, enforcer, uuid.New(), uuid.New()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/roles/abc/users", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Nil(t, input, "o PDP não deve ser consultado")
+	assert.Empty(t, enforcer.violations)
+}
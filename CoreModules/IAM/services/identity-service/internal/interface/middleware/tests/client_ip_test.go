@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// TestClientIP verifica que X-Forwarded-For só indica o cliente nos pedidos dos proxies de confiança
+func TestClientIP(t *testing.T) {
+	trusted := middleware.ParseTrustedProxies(zerolog.Nop(), []string{"10.0.0.0/8", "inválido"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		proxies    []string
+		expected   string
+	}{
+		{name: "sem proxies de confiança ignora o cabeçalho", remoteAddr: "10.0.0.5:443", forwarded: "192.0.2.1", expected: "10.0.0.5"},
+		{name: "pedido direto não pode indicar outro endereço", remoteAddr: "198.51.100.4:443", forwarded: "192.0.2.1", proxies: []string{"10.0.0.0/8"}, expected: "198.51.100.4"},
+		{name: "primeiro salto não confiável a partir da direita", remoteAddr: "10.0.0.5:443", forwarded: "192.0.2.1, 203.0.113.9, 10.0.0.7", proxies: []string{"10.0.0.0/8"}, expected: "203.0.113.9"},
+		{name: "todos os saltos confiáveis", remoteAddr: "10.0.0.5:443", forwarded: "10.0.0.8, 10.0.0.7", proxies: []string{"10.0.0.0/8"}, expected: "10.0.0.8"},
+		{name: "sem cabeçalho", remoteAddr: "10.0.0.5:443", proxies: []string{"10.0.0.0/8"}, expected: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			proxies := trusted
+			if tt.proxies == nil {
+				proxies = nil
+			}
+			assert.Equal(t, tt.expected, middleware.ClientIP(r, proxies))
+		})
+	}
+}
+
+// recordingDecisionRecorder guarda a última decisão de autorização registada
+type recordingDecisionRecorder struct {
+	last *model.PermissionDecision
+}
+
+func (r *recordingDecisionRecorder) Record(ctx context.Context, decision *model.PermissionDecision) {
+	r.last = decision
+}
+
+// TestAuthorizationRecordsTrustedClientIP verifica que a decisão registada não aceita o endereço indicado pelo cliente
+func TestAuthorizationRecordsTrustedClientIP(t *testing.T) {
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"allow": true}})
+	}))
+	defer opa.Close()
+
+	serve := func(trustedProxies []string) *model.PermissionDecision {
+		recorder := &recordingDecisionRecorder{}
+		config := middleware.DefaultAuthzConfig()
+		config.OPAEndpoint = opa.URL
+		config.DecisionRecorder = recorder
+		config.TrustedProxies = trustedProxies
+
+		router := mux.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), middleware.TenantIDContextKey, uuid.NewString())
+				ctx = context.WithValue(ctx, middleware.UserIDContextKey, uuid.NewString())
+				ctx = context.WithValue(ctx, middleware.RolesContextKey, []string{"analyst"})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		})
+		router.Use(middleware.AuthorizationMiddleware(zerolog.Nop(), config))
+		router.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodGet)
+
+		req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		req.RemoteAddr = "10.0.0.5:443"
+		req.Header.Set("X-Forwarded-For", "192.0.2.1, 203.0.113.9")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.NotNil(t, recorder.last)
+		return recorder.last
+	}
+
+	assert.Equal(t, "10.0.0.5", serve(nil).ClientIP)
+	assert.Equal(t, "203.0.113.9", serve([]string{"10.0.0.0/8"}).ClientIP)
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// recordingEmergencyAuthorizer guarda o último pedido verificado e devolve a decisão configurada
+type recordingEmergencyAuthorizer struct {
+	decision *model.EmergencyAccessDecision
+	err      error
+	last     *model.EmergencyAccessRequest
+}
+
+func (a *recordingEmergencyAuthorizer) Authorize(ctx context.Context, req *model.EmergencyAccessRequest) (*model.EmergencyAccessDecision, error) {
+	a.last = req
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.decision, nil
+}
+
+// serveEmergencyAccess executa um pedido pelo middleware, simulando a sessão autenticada quando userID é indicado
+func serveEmergencyAccess(config middleware.EmergencyAccessConfig, userID uuid.UUID, prepare func(*http.Request)) *httptest.ResponseRecorder {
+	handler := middleware.EmergencyAccessMiddleware(zerolog.Nop(), config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/roles/42?force=true", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	ctx := context.WithValue(req.Context(), middleware.TenantIDContextKey, uuid.New())
+	if userID != uuid.Nil {
+		ctx = context.WithValue(ctx, middleware.UserIDContextKey, userID)
+	}
+	req = req.WithContext(ctx)
+	if prepare != nil {
+		prepare(req)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestEmergencyAccessSealedAccount verifica que uma conta de emergência sem ativação recebe 403
+func TestEmergencyAccessSealedAccount(t *testing.T) {
+	authorizer := &recordingEmergencyAuthorizer{decision: &model.EmergencyAccessDecision{
+		EmergencyAccount: true,
+		Reason:           model.EmergencyDenyReasonSealed,
+	}}
+	config := middleware.DefaultEmergencyAccessConfig()
+	config.Authorizer = authorizer
+
+	rec := serveEmergencyAccess(config, uuid.New(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "emergency_account_sealed")
+
+	authorizer.decision.Reason = model.EmergencyDenyReasonRecordingFailed
+	rec = serveEmergencyAccess(config, uuid.New(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "emergency_recording_failed")
+}
+
+// TestEmergencyAccessRecordsRequest verifica que o pedido permitido é entregue ao autorizador para ser gravado
+func TestEmergencyAccessRecordsRequest(t *testing.T) {
+	activationID := uuid.New()
+	authorizer := &recordingEmergencyAuthorizer{decision: &model.EmergencyAccessDecision{
+		Allowed:          true,
+		EmergencyAccount: true,
+		ActivationID:     &activationID,
+	}}
+	config := middleware.DefaultEmergencyAccessConfig()
+	config.Authorizer = authorizer
+	config.TrustedProxies = []string{"10.0.0.0/8"}
+
+	userID := uuid.New()
+	rec := serveEmergencyAccess(config, userID, func(r *http.Request) {
+		r.Header.Set("X-Forwarded-For", "203.0.113.9")
+		r.Header.Set("X-Request-ID", "req-1")
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.NotNil(t, authorizer.last)
+	assert.Equal(t, userID, authorizer.last.UserID)
+	assert.Equal(t, http.MethodDelete, authorizer.last.Method)
+	assert.Equal(t, "/api/v1/roles/42", authorizer.last.Path)
+	assert.Equal(t, "force=true", authorizer.last.Query)
+	assert.Equal(t, "203.0.113.9", authorizer.last.IPAddress)
+	assert.Equal(t, "req-1", authorizer.last.RequestID)
+}
+
+// TestEmergencyAccessSkipsAnonymous verifica que os pedidos sem usuário autenticado não são verificados
+func TestEmergencyAccessSkipsAnonymous(t *testing.T) {
+	authorizer := &recordingEmergencyAuthorizer{err: errors.New("não deve ser chamado")}
+	config := middleware.DefaultEmergencyAccessConfig()
+	config.Authorizer = authorizer
+
+	assert.Equal(t, http.StatusNoContent, serveEmergencyAccess(config, uuid.Nil, nil).Code)
+	assert.Nil(t, authorizer.last)
+}
+
+// TestEmergencyAccessAuthorizerFailure verifica que as falhas do autorizador recusam o pedido
+func TestEmergencyAccessAuthorizerFailure(t *testing.T) {
+	config := middleware.DefaultEmergencyAccessConfig()
+	config.Authorizer = &recordingEmergencyAuthorizer{err: errors.New("base de dados indisponível")}
+
+	rec := serveEmergencyAccess(config, uuid.New(), nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "emergency_access_unavailable")
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// recordingEvaluator guarda o último pedido avaliado e recusa os endereços bloqueados
+type recordingEvaluator struct {
+	blocked map[string]bool
+	err     error
+	last    *model.NetworkAccessRequest
+}
+
+func (e *recordingEvaluator) Evaluate(ctx context.Context, req *model.NetworkAccessRequest) (*model.NetworkDecision, error) {
+	e.last = req
+	if e.err != nil {
+		return nil, e.err
+	}
+	if e.blocked[req.IPAddress] {
+		return &model.NetworkDecision{Allowed: false, Reason: model.NetworkBlockReasonNotAllowed, Scope: model.NetworkPolicyScopeAdminAPI}, nil
+	}
+	return &model.NetworkDecision{Allowed: true}, nil
+}
+
+// serveNetworkPolicy executa um pedido pelo middleware, simulando a sessão autenticada quando userID é indicado
+func serveNetworkPolicy(config middleware.NetworkPolicyConfig, tenantID, userID uuid.UUID, prepare func(*http.Request)) *httptest.ResponseRecorder {
+	handler := middleware.NetworkPolicyMiddleware(zerolog.Nop(), config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	ctx := context.WithValue(req.Context(), middleware.TenantIDContextKey, tenantID)
+	if userID != uuid.Nil {
+		ctx = context.WithValue(ctx, middleware.UserIDContextKey, userID)
+	}
+	req = req.WithContext(ctx)
+	if prepare != nil {
+		prepare(req)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestNetworkPolicyBlocksAddress verifica que um endereço recusado pelo avaliador recebe 403
+func TestNetworkPolicyBlocksAddress(t *testing.T) {
+	evaluator := &recordingEvaluator{blocked: map[string]bool{"10.0.0.5": true}}
+	config := middleware.DefaultNetworkPolicyConfig()
+	config.Evaluator = evaluator
+
+	rec := serveNetworkPolicy(config, uuid.New(), uuid.New(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "network_access_denied")
+	require.NotNil(t, evaluator.last)
+	assert.True(t, evaluator.last.AdminAPI)
+}
+
+// TestNetworkPolicyForwardedForRequiresTrustedProxy verifica que X-Forwarded-For só é usado vindo de um proxy de confiança
+func TestNetworkPolicyForwardedForRequiresTrustedProxy(t *testing.T) {
+	evaluator := &recordingEvaluator{}
+	config := middleware.DefaultNetworkPolicyConfig()
+	config.Evaluator = evaluator
+	forwarded := func(r *http.Request) { r.Header.Set("X-Forwarded-For", "192.0.2.1, 203.0.113.9, 10.0.0.7") }
+
+	serveNetworkPolicy(config, uuid.New(), uuid.Nil, forwarded)
+	assert.Equal(t, "10.0.0.5", evaluator.last.IPAddress)
+
+	config.TrustedProxies = []string{"10.0.0.0/8"}
+	serveNetworkPolicy(config, uuid.New(), uuid.Nil, forwarded)
+	assert.Equal(t, "203.0.113.9", evaluator.last.IPAddress)
+}
+
+// TestNetworkPolicyIgnoresUserHeader verifica que o usuário do acesso de emergência só vem da sessão
+func TestNetworkPolicyIgnoresUserHeader(t *testing.T) {
+	evaluator := &recordingEvaluator{}
+	config := middleware.DefaultNetworkPolicyConfig()
+	config.Evaluator = evaluator
+
+	serveNetworkPolicy(config, uuid.New(), uuid.Nil, func(r *http.Request) { r.Header.Set("X-User-ID", uuid.NewString()) })
+	assert.Equal(t, uuid.Nil, evaluator.last.UserID)
+
+	userID := uuid.New()
+	serveNetworkPolicy(config, uuid.New(), userID, nil)
+	assert.Equal(t, userID, evaluator.last.UserID)
+}
+
+// TestNetworkPolicyEvaluatorFailure verifica que as falhas de avaliação recusam o pedido, exceto com FailOpen
+func TestNetworkPolicyEvaluatorFailure(t *testing.T) {
+	config := middleware.DefaultNetworkPolicyConfig()
+	config.Evaluator = &recordingEvaluator{err: errors.New("base de dados indisponível")}
+
+	assert.Equal(t, http.StatusServiceUnavailable, serveNetworkPolicy(config, uuid.New(), uuid.Nil, nil).Code)
+
+	config.FailOpen = true
+	assert.Equal(t, http.StatusNoContent, serveNetworkPolicy(config, uuid.New(), uuid.Nil, nil).Code)
+}
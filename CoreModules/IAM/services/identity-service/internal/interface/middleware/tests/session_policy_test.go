@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/domain/model"
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// recordingEnforcer guarda a última verificação e responde com o motivo configurado
+type recordingEnforcer struct {
+	reason string
+	err    error
+	last   *model.SessionCheck
+}
+
+func (e *recordingEnforcer) CheckSession(ctx context.Context, check *model.SessionCheck) (*model.SessionDecision, error) {
+	e.last = check
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &model.SessionDecision{Allowed: e.reason == "", Reason: e.reason}, nil
+}
+
+// serveSessionPolicy executa um pedido pelo middleware, simulando a sessão autenticada quando tenantID é indicado
+func serveSessionPolicy(config middleware.SessionPolicyConfig, tenantID uuid.UUID, values map[interface{}]interface{}) *httptest.ResponseRecorder {
+	handler := middleware.SessionPolicyMiddleware(zerolog.Nop(), config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	ctx := req.Context()
+	if tenantID != uuid.Nil {
+		ctx = context.WithValue(ctx, middleware.TenantIDContextKey, tenantID)
+	}
+	for key, value := range values {
+		ctx = context.WithValue(ctx, key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(ctx))
+	return rec
+}
+
+// TestSessionPolicyPassesSessionClaims verifica que a sessão e a validade do token chegam ao verificador
+func TestSessionPolicyPassesSessionClaims(t *testing.T) {
+	enforcer := &recordingEnforcer{}
+	config := middleware.DefaultSessionPolicyConfig()
+	config.Enforcer = enforcer
+
+	tenantID, userID := uuid.New(), uuid.New()
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	rec := serveSessionPolicy(config, tenantID, map[interface{}]interface{}{
+		middleware.UserIDContextKey:         userID,
+		middleware.SessionIDContextKey:      "sess-1",
+		middleware.MarketContextKey:         "eu",
+		middleware.TokenIssuedAtContextKey:  issuedAt,
+		middleware.TokenExpiresAtContextKey: issuedAt.Add(5 * time.Minute),
+		middleware.AuthTimeContextKey:       issuedAt,
+	})
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.NotNil(t, enforcer.last)
+	assert.Equal(t, tenantID, enforcer.last.TenantID)
+	assert.Equal(t, userID, enforcer.last.UserID)
+	assert.Equal(t, "sess-1", enforcer.last.SessionID)
+	assert.Equal(t, "eu", enforcer.last.Market)
+	assert.Equal(t, issuedAt.Add(5*time.Minute), enforcer.last.ExpiresAt)
+}
+
+// TestSessionPolicyRejectsSession verifica que cada motivo de recusa responde 401 com o código próprio
+func TestSessionPolicyRejectsSession(t *testing.T) {
+	tests := map[string]string{
+		model.SessionRejectTokenLifetime: "token_expired",
+		model.SessionRejectAbsolute:      "session_expired",
+		model.SessionRejectIdle:          "session_idle_timeout",
+	}
+	for reason, code := range tests {
+		t.Run(reason, func(t *testing.T) {
+			config := middleware.DefaultSessionPolicyConfig()
+			config.Enforcer = &recordingEnforcer{reason: reason}
+
+			rec := serveSessionPolicy(config, uuid.New(), nil)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Body.String(), code)
+		})
+	}
+}
+
+// TestSessionPolicySkipsUnauthenticated verifica que os pedidos sem sessão autenticada não são verificados
+func TestSessionPolicySkipsUnauthenticated(t *testing.T) {
+	enforcer := &recordingEnforcer{reason: model.SessionRejectAbsolute}
+	config := middleware.DefaultSessionPolicyConfig()
+	config.Enforcer = enforcer
+
+	assert.Equal(t, http.StatusNoContent, serveSessionPolicy(config, uuid.Nil, nil).Code)
+	assert.Nil(t, enforcer.last)
+}
+
+// TestSessionPolicyEnforcerFailure verifica que as falhas de verificação recusam o pedido, exceto com FailOpen
+func TestSessionPolicyEnforcerFailure(t *testing.T) {
+	config := middleware.DefaultSessionPolicyConfig()
+	config.Enforcer = &recordingEnforcer{err: errors.New("base de dados indisponível")}
+
+	assert.Equal(t, http.StatusServiceUnavailable, serveSessionPolicy(config, uuid.New(), nil).Code)
+
+	config.FailOpen = true
+	assert.Equal(t, http.StatusNoContent, serveSessionPolicy(config, uuid.New(), nil).Code)
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/identity-service/internal/interface/middleware"
+)
+
+// stepUpRouter monta um router com uma rota sensível e uma rota comum, simulando a sessão autenticada
+func stepUpRouter(market, mfaLevel string, authTime time.Time) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.MarketContextKey, market)
+			ctx = context.WithValue(ctx, middleware.MFALevelContextKey, mfaLevel)
+			if !authTime.IsZero() {
+				ctx = context.WithValue(ctx, middleware.AuthTimeContextKey, authTime)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	api.Use(middleware.StepUpMiddleware(zerolog.Nop(), middleware.DefaultStepUpConfig()))
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	api.HandleFunc("/roles/{id}", ok).Methods(http.MethodDelete)
+	api.HandleFunc("/roles/{id}", ok).Methods(http.MethodGet)
+	api.HandleFunc("/system-roles/sync", ok).Methods(http.MethodPost)
+	return router
+}
+
+func serveStepUp(router *mux.Router, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+// TestStepUpAllowsCompliantSession verifica que uma sessão com MFA suficiente e recente prossegue
+func TestStepUpAllowsCompliantSession(t *testing.T) {
+	router := stepUpRouter("Angola", middleware.MFALevelHigh, time.Now().Add(-time.Minute))
+
+	assert.Equal(t, http.StatusNoContent, serveStepUp(router, http.MethodDelete, "/api/v1/roles/abc").Code)
+	assert.Equal(t, http.StatusNoContent, serveStepUp(router, http.MethodPost, "/api/v1/system-roles/sync").Code)
+}
+
+// TestStepUpIgnoresNonSensitiveRoutes verifica que rotas não marcadas não exigem step-up
+func TestStepUpIgnoresNonSensitiveRoutes(t *testing.T) {
+	router := stepUpRouter("angola", middleware.MFALevelBasic, time.Time{})
+
+	assert.Equal(t, http.StatusNoContent, serveStepUp(router, http.MethodGet, "/api/v1/roles/abc").Code)
+}
+
+// TestStepUpChallenges verifica o desafio estruturado para cada motivo
+func TestStepUpChallenges(t *testing.T) {
+	cases := []struct {
+		name     string
+		market   string
+		mfaLevel string
+		authTime time.Time
+		reason   string
+		required string
+	}{
+		{"nível insuficiente para o mercado", "angola", middleware.MFALevelMedium, time.Now(), middleware.StepUpReasonMFALevel, middleware.MFALevelHigh},
+		{"autenticação antiga", "brazil", middleware.MFALevelHigh, time.Now().Add(-time.Hour), middleware.StepUpReasonAuthExpired, middleware.MFALevelMedium},
+		{"sessão sem contexto de autenticação", "eu", "", time.Time{}, middleware.StepUpReasonMissingContext, middleware.MFALevelHigh},
+		{"mercado desconhecido usa o padrão", "atlantis", middleware.MFALevelBasic, time.Now(), middleware.StepUpReasonMFALevel, middleware.MFALevelMedium},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveStepUp(stepUpRouter(tc.market, tc.mfaLevel, tc.authTime), http.MethodDelete, "/api/v1/roles/abc")
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.True(t, strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), `Bearer error="insufficient_user_authentication"`))
+
+			var resp middleware.StepUpChallengeResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, "step_up_required", resp.Code)
+			assert.Equal(t, tc.reason, resp.Challenge.Reason)
+			assert.Equal(t, tc.required, resp.Challenge.RequiredMFALevel)
+			assert.Positive(t, resp.Challenge.MaxAuthAgeSeconds)
+		})
+	}
+}
+
+// TestMFALevelSatisfies verifica a ordenação dos níveis de MFA
+func TestMFALevelSatisfies(t *testing.T) {
+	assert.True(t, middleware.MFALevelSatisfies(middleware.MFALevelAdvanced, middleware.MFALevelHigh))
+	assert.True(t, middleware.MFALevelSatisfies("HIGH", middleware.MFALevelHigh))
+	assert.False(t, middleware.MFALevelSatisfies(middleware.MFALevelBasic, middleware.MFALevelMedium))
+	assert.False(t, middleware.MFALevelSatisfies("unknown", middleware.MFALevelNone))
+}
@@ -22,6 +22,9 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 
 	"github.com/innovabiz/iam/internal/domain/services"
@@ -40,6 +43,9 @@ type Config struct {
 	Tracer       tracing.Tracer
 	GroupService services.GroupService
 	UserService  services.UserService
+
+	// Websocket configura o transporte das subscriptions; nil usa o transporte padrão do gqlgen
+	Websocket *WebsocketConfig
 }
 
 // NewGraphQLServer cria um novo servidor GraphQL
@@ -74,7 +80,12 @@ func NewGraphQLServer(cfg Config) *handler.Server {
 
 	// Registrar os scalars customizados
 	config.Resolvers = resolver
-	server := handler.NewDefaultServer(generated.NewExecutableSchema(config))
+	var server *handler.Server
+	if cfg.Websocket != nil {
+		server = newServerWithWebsocket(generated.NewExecutableSchema(config), *cfg.Websocket)
+	} else {
+		server = handler.NewDefaultServer(generated.NewExecutableSchema(config))
+	}
 
 	// Adicionar middlewares para observabilidade
 	server.Use(tracing.NewGraphQLMiddleware(cfg.Tracer))
@@ -84,6 +95,27 @@ func NewGraphQLServer(cfg Config) *handler.Server {
 	return server
 }
 
+// newServerWithWebsocket cria o servidor com os transportes e extensões do servidor padrão,
+// substituindo o transporte WebSocket pelo transporte autenticado e limitado das subscriptions
+func newServerWithWebsocket(schema graphql.ExecutableSchema, websocketConfig WebsocketConfig) *handler.Server {
+	server := handler.New(schema)
+
+	server.AddTransport(NewWebsocketTransport(websocketConfig))
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.AddTransport(transport.MultipartForm{})
+
+	server.SetQueryCache(lru.New(1000))
+
+	server.Use(extension.Introspection{})
+	server.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
+
+	return server
+}
+
 // NewPlaygroundHandler cria um handler para o GraphQL Playground
 func NewPlaygroundHandler(endpoint string) http.Handler {
 	return playground.Handler("INNOVABIZ IAM GraphQL", endpoint)
@@ -0,0 +1,269 @@
+/*
+ * INNOVABIZ IAM - Identity Service
+ * Copyright (c) 2025 INNOVABIZ
+ *
+ * Testes unitários para o transporte WebSocket das subscriptions GraphQL.
+ * Valida a autenticação no connection_init, os limites de conexões no total e por
+ * usuário, a libertação das conexões quando são fechadas e a verificação de origem.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/testserver"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/internal/infrastructure/auth"
+	"github.com/innovabiz/iam/internal/interfaces/graphql/config"
+)
+
+var (
+	websocketUserA = uuid.MustParse("0b8f7c1e-1f0a-4c55-9a57-3f2d5a1e9c01")
+	websocketUserB = uuid.MustParse("0b8f7c1e-1f0a-4c55-9a57-3f2d5a1e9c02")
+	websocketUserC = uuid.MustParse("0b8f7c1e-1f0a-4c55-9a57-3f2d5a1e9c03")
+)
+
+// websocketAuthenticator aceita os tokens "token-<usuário>" e o token "sem-usuario",
+// que é válido mas não coloca o usuário no contexto
+func websocketAuthenticator(ctx context.Context, token string) (context.Context, error) {
+	users := map[string]uuid.UUID{
+		"token-a": websocketUserA,
+		"token-b": websocketUserB,
+		"token-c": websocketUserC,
+	}
+	if token == "sem-usuario" {
+		return ctx, nil
+	}
+	userID, ok := users[token]
+	if !ok {
+		return ctx, errors.New("token expirado")
+	}
+	return auth.EnrichContextWithUser(ctx, &auth.User{ID: userID, TenantID: uuid.New()}), nil
+}
+
+// initConnection executa o connection_init do transporte com o payload indicado
+func initConnection(t *testing.T, ws transport.Websocket, payload transport.InitPayload) (context.Context, error) {
+	t.Helper()
+	require.NotNil(t, ws.InitFunc)
+	ctx, ack, err := ws.InitFunc(context.Background(), payload)
+	assert.Nil(t, ack)
+	return ctx, err
+}
+
+func bearer(token string) transport.InitPayload {
+	return transport.InitPayload{"Authorization": "Bearer " + token}
+}
+
+func TestWebsocketInitFuncRejectsUnauthenticatedConnections(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator config.WebsocketAuthenticator
+		payload       transport.InitPayload
+	}{
+		{"sem autenticador configurado", nil, bearer("token-a")},
+		{"sem payload", websocketAuthenticator, transport.InitPayload{}},
+		{"token vazio", websocketAuthenticator, transport.InitPayload{"Authorization": "Bearer  "}},
+		{"token inválido", websocketAuthenticator, bearer("token-x")},
+		{"autenticação sem usuário", websocketAuthenticator, bearer("sem-usuario")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := config.NewWebsocketTransport(config.WebsocketConfig{Authenticate: tt.authenticator})
+
+			_, err := initConnection(t, ws, tt.payload)
+			assert.ErrorIs(t, err, config.ErrWebsocketUnauthenticated)
+		})
+	}
+}
+
+func TestWebsocketInitFuncAuthenticatesConnection(t *testing.T) {
+	var received string
+	ws := config.NewWebsocketTransport(config.WebsocketConfig{
+		Authenticate: func(ctx context.Context, token string) (context.Context, error) {
+			received = token
+			return websocketAuthenticator(ctx, token)
+		},
+	})
+
+	ctx, err := initConnection(t, ws, bearer("token-a"))
+	require.NoError(t, err)
+	assert.Equal(t, "token-a", received, "o prefixo Bearer é removido antes da autenticação")
+
+	user, err := auth.GetUserFromContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, websocketUserA, user.ID)
+
+	// Clientes que enviam a chave em minúsculas e o token sem prefixo também são aceites
+	_, err = initConnection(t, ws, transport.InitPayload{"authorization": "token-b"})
+	require.NoError(t, err)
+	assert.Equal(t, "token-b", received)
+}
+
+func TestWebsocketConnectionLimitReleasedOnClose(t *testing.T) {
+	ws := config.NewWebsocketTransport(config.WebsocketConfig{
+		Authenticate:          websocketAuthenticator,
+		MaxConnections:        2,
+		MaxConnectionsPerUser: 1,
+	})
+	require.NotNil(t, ws.CloseFunc)
+
+	ctxA, err := initConnection(t, ws, bearer("token-a"))
+	require.NoError(t, err)
+
+	_, err = initConnection(t, ws, bearer("token-a"))
+	assert.ErrorIs(t, err, config.ErrWebsocketConnectionLimit, "limite por usuário")
+
+	ctxB, err := initConnection(t, ws, bearer("token-b"))
+	require.NoError(t, err)
+
+	_, err = initConnection(t, ws, bearer("token-c"))
+	assert.ErrorIs(t, err, config.ErrWebsocketConnectionLimit, "limite total")
+
+	// Fechar uma conexão recusada no connection_init não liberta as conexões contadas
+	ws.CloseFunc(context.Background(), websocket.CloseNormalClosure)
+	_, err = initConnection(t, ws, bearer("token-c"))
+	assert.ErrorIs(t, err, config.ErrWebsocketConnectionLimit)
+
+	ws.CloseFunc(ctxA, websocket.CloseNormalClosure)
+	ctxC, err := initConnection(t, ws, bearer("token-c"))
+	require.NoError(t, err, "a conexão fechada liberta o limite total")
+
+	ws.CloseFunc(ctxB, websocket.CloseGoingAway)
+	ws.CloseFunc(ctxC, websocket.CloseGoingAway)
+	_, err = initConnection(t, ws, bearer("token-a"))
+	require.NoError(t, err, "a conexão fechada liberta o limite do usuário")
+}
+
+func TestWebsocketConnectionLimiter(t *testing.T) {
+	limiter := config.NewWebsocketConnectionLimiter(3, 2)
+
+	require.NoError(t, limiter.Acquire(websocketUserA))
+	require.NoError(t, limiter.Acquire(websocketUserA))
+	assert.ErrorIs(t, limiter.Acquire(websocketUserA), config.ErrWebsocketConnectionLimit)
+	require.NoError(t, limiter.Acquire(websocketUserB))
+	assert.ErrorIs(t, limiter.Acquire(websocketUserC), config.ErrWebsocketConnectionLimit)
+	assert.Equal(t, 3, limiter.ActiveConnections())
+
+	// Libertar um usuário sem conexões não altera a contagem
+	limiter.Release(websocketUserC)
+	assert.Equal(t, 3, limiter.ActiveConnections())
+
+	limiter.Release(websocketUserA)
+	assert.Equal(t, 2, limiter.ActiveConnections())
+	require.NoError(t, limiter.Acquire(websocketUserC))
+
+	// Valores não positivos usam os limites padrão
+	limiter = config.NewWebsocketConnectionLimiter(0, -1)
+	for i := 0; i < config.DefaultWebsocketMaxConnectionsPerUser; i++ {
+		require.NoError(t, limiter.Acquire(websocketUserA))
+	}
+	assert.ErrorIs(t, limiter.Acquire(websocketUserA), config.ErrWebsocketConnectionLimit)
+}
+
+// websocketMessage é uma mensagem do protocolo graphql-ws
+type websocketMessage struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// openWebsocket abre uma conexão com o servidor e envia o connection_init com o token
+func openWebsocket(t *testing.T, url, token string) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(strings.Replace(url, "http://", "ws://", 1), nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	payload := map[string]string{}
+	if token != "" {
+		payload["Authorization"] = "Bearer " + token
+	}
+	require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "connection_init", "payload": payload}))
+	return conn
+}
+
+// readWebsocket lê a próxima mensagem da conexão
+func readWebsocket(t *testing.T, conn *websocket.Conn) websocketMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var msg websocketMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestWebsocketTransportOverConnection(t *testing.T) {
+	server := testserver.New()
+	server.AddTransport(config.NewWebsocketTransport(config.WebsocketConfig{
+		Authenticate:          websocketAuthenticator,
+		MaxConnectionsPerUser: 1,
+	}))
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	// Conexões sem credenciais são recusadas
+	anonymous := openWebsocket(t, srv.URL, "")
+	defer anonymous.Close()
+	msg := readWebsocket(t, anonymous)
+	assert.Equal(t, "connection_error", msg.Type)
+	assert.Contains(t, string(msg.Payload), config.ErrWebsocketUnauthenticated.Error())
+
+	first := openWebsocket(t, srv.URL, "token-a")
+	assert.Equal(t, "connection_ack", readWebsocket(t, first).Type)
+
+	second := openWebsocket(t, srv.URL, "token-a")
+	defer second.Close()
+	msg = readWebsocket(t, second)
+	assert.Equal(t, "connection_error", msg.Type)
+	assert.Contains(t, string(msg.Payload), config.ErrWebsocketConnectionLimit.Error())
+
+	// Fechar a conexão liberta o limite do usuário para uma nova conexão
+	require.NoError(t, first.WriteJSON(map[string]string{"type": "connection_terminate"}))
+	first.Close()
+	assert.Eventually(t, func() bool {
+		conn := openWebsocket(t, srv.URL, "token-a")
+		defer conn.Close()
+		return readWebsocket(t, conn).Type == "connection_ack"
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestWebsocketTransportCheckOrigin(t *testing.T) {
+	ws := config.NewWebsocketTransport(config.WebsocketConfig{Authenticate: websocketAuthenticator})
+	assert.Nil(t, ws.Upgrader.CheckOrigin, "sem origens configuradas aplica a verificação de mesma origem")
+
+	ws = config.NewWebsocketTransport(config.WebsocketConfig{
+		Authenticate:   websocketAuthenticator,
+		AllowedOrigins: []string{"https://admin.innovabiz.com/"},
+	})
+	require.NotNil(t, ws.Upgrader.CheckOrigin)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://admin.innovabiz.com", true},
+		{"https://ADMIN.innovabiz.com", true},
+		{"", true},
+		{"https://evil.example.com", false},
+		{"http://admin.innovabiz.com", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		assert.Equal(t, tt.allowed, ws.Upgrader.CheckOrigin(req), tt.origin)
+	}
+}
@@ -0,0 +1,289 @@
+// Middleware de Validação de Tokens Delegados (RFC 8693) para os serviços da Plataforma INNOVABIZ
+// Conformidade: ISO/IEC 27001:2022, NIST SP 800-53 (AC-6, IA-9), PCI DSS v4.0, PSD2, OWASP ASVS 4.0
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Chave do contexto para a delegação do pedido
+type delegationContextKey struct{}
+
+// DelegatedTokenConfig configura a validação dos tokens delegados emitidos pela troca de tokens
+// do identity-service. Cada serviço de destino (ex.: o bureau de crédito) valida apenas os tokens
+// emitidos para a sua audiência
+//
+// Limitação: os tokens são assinados com HS256 e a chave partilhada JWT_SECRET. Qualquer serviço
+// que valide tokens delegados conhece essa chave e pode, por isso, emitir tokens para qualquer
+// audiência, sujeito, tenant ou cadeia de atores. A audiência e a cadeia de delegação só protegem
+// contra serviços que não têm a chave; a configuração deve ser distribuída apenas a serviços de
+// confiança equivalente à do identity-service, até os tokens passarem a assinatura assimétrica
+type DelegatedTokenConfig struct {
+	// Chave HMAC com que o identity-service assina os tokens (JWT_SECRET); permite também emiti-los
+	Secret []byte `json:"-"`
+
+	// Emissor esperado na claim iss; vazio aceita qualquer emissor
+	Issuer string `json:"issuer"`
+
+	// Audiência do serviço, obrigatória; os tokens destinados a outros serviços são recusados
+	Audience string `json:"audience"`
+
+	// Escopos exigidos em todos os pedidos; RequireScopes acrescenta escopos por rota
+	RequiredScopes []string `json:"required_scopes"`
+
+	// Serviços aceites como ator atual; vazio aceita qualquer serviço autorizado pela política da audiência
+	AllowedActors []string `json:"allowed_actors"`
+
+	// Número máximo de atores na cadeia de delegação; zero não limita
+	MaxDelegationDepth int `json:"max_delegation_depth"`
+
+	// Aceita também tokens sem claim act, emitidos diretamente para o usuário
+	AllowDirectTokens bool `json:"allow_direct_tokens"`
+
+	// Diferença de relógio tolerada na validade dos tokens
+	Leeway time.Duration `json:"leeway"`
+
+	// Caminhos não verificados: o próprio caminho e os seus subcaminhos (ex.: /health e /health/live,
+	// mas não /healthz); uma entrada terminada em / abrange apenas os subcaminhos
+	SkipPaths []string `json:"skip_paths"`
+}
+
+// DefaultDelegatedTokenConfig retorna a configuração padrão para a audiência indicada
+func DefaultDelegatedTokenConfig(audience string, secret []byte) DelegatedTokenConfig {
+	return DelegatedTokenConfig{
+		Secret:    secret,
+		Audience:  audience,
+		Leeway:    30 * time.Second,
+		SkipPaths: []string{"/health", "/ready", "/metrics"},
+	}
+}
+
+// DelegationActor identifica um serviço da cadeia de delegação (claim act)
+type DelegationActor struct {
+	Subject  string           `json:"sub"`
+	ClientID string           `json:"client_id,omitempty"`
+	Actor    *DelegationActor `json:"act,omitempty"`
+}
+
+// Identifier retorna o client_id do ator ou, na sua falta, o subject
+func (a *DelegationActor) Identifier() string {
+	if a.ClientID != "" {
+		return a.ClientID
+	}
+	return a.Subject
+}
+
+// Delegation descreve o token delegado de um pedido validado pelo middleware
+type Delegation struct {
+	TokenID   string           `json:"token_id"`
+	Subject   string           `json:"subject"`
+	TenantID  string           `json:"tenant_id"`
+	Username  string           `json:"username,omitempty"`
+	Market    string           `json:"market,omitempty"`
+	Scopes    []string         `json:"scopes"`
+	Actor     *DelegationActor `json:"actor,omitempty"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// HasScope indica se o token inclui o escopo
+func (d *Delegation) HasScope(scope string) bool {
+	for _, s := range d.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ActorChain retorna os identificadores dos atores, do atual ao primeiro
+func (d *Delegation) ActorChain() []string {
+	var chain []string
+	for actor := d.Actor; actor != nil; actor = actor.Actor {
+		chain = append(chain, actor.Identifier())
+	}
+	return chain
+}
+
+// delegatedClaims são as claims JWT dos tokens emitidos pela troca de tokens
+type delegatedClaims struct {
+	jwt.RegisteredClaims
+	TenantID string           `json:"tid,omitempty"`
+	Username string           `json:"preferred_username,omitempty"`
+	Market   string           `json:"market,omitempty"`
+	Scope    string           `json:"scope,omitempty"`
+	Actor    *DelegationActor `json:"act,omitempty"`
+}
+
+// Erros da validação dos tokens delegados
+var (
+	ErrDelegatedTokenMissing = errors.New("token de autorização não fornecido")
+	ErrDelegatedTokenInvalid = errors.New("token delegado inválido ou expirado")
+	ErrDelegationNotAllowed  = errors.New("cadeia de delegação não permitida")
+)
+
+// DelegatedTokenMiddleware valida o token Bearer dos pedidos: assinatura, emissor, audiência,
+// validade, escopos exigidos, serviço que age e profundidade da cadeia de delegação. Os pedidos
+// aceites levam a Delegation no contexto; os recusados recebem 401 (token inválido) ou 403
+// (escopo ou delegação não permitidos), com o cabeçalho WWW-Authenticate do RFC 6750
+func DelegatedTokenMiddleware(config DelegatedTokenConfig) (func(http.Handler) http.Handler, error) {
+	if len(config.Secret) == 0 {
+		return nil, errors.New("chave de validação dos tokens delegados não configurada")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("audiência do serviço não configurada")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(config.Audience),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	parser := jwt.NewParser(options...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSkippedPath(r.URL.Path, config.SkipPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			delegation, err := parseDelegatedToken(parser, config, r)
+			if err != nil {
+				respondDelegationError(w, http.StatusUnauthorized, "invalid_token", err)
+				return
+			}
+			if err := checkDelegation(config, delegation); err != nil {
+				respondDelegationError(w, http.StatusForbidden, "insufficient_scope", err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), delegationContextKey{}, delegation)))
+		})
+	}, nil
+}
+
+// RequireScopes recusa com 403 os pedidos cujo token delegado não inclui todos os escopos
+// Deve ser registado depois do DelegatedTokenMiddleware, nas rotas que exigem escopos próprios
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			delegation, ok := DelegationFromContext(r.Context())
+			if !ok {
+				respondDelegationError(w, http.StatusUnauthorized, "invalid_token", ErrDelegatedTokenMissing)
+				return
+			}
+			for _, scope := range scopes {
+				if !delegation.HasScope(scope) {
+					respondDelegationError(w, http.StatusForbidden, "insufficient_scope",
+						fmt.Errorf("escopo %s não concedido", scope))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DelegationFromContext retorna a delegação validada do pedido
+func DelegationFromContext(ctx context.Context) (*Delegation, bool) {
+	delegation, ok := ctx.Value(delegationContextKey{}).(*Delegation)
+	return delegation, ok
+}
+
+// parseDelegatedToken lê e valida o token Bearer do pedido
+func parseDelegatedToken(parser *jwt.Parser, config DelegatedTokenConfig, r *http.Request) (*Delegation, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return nil, ErrDelegatedTokenMissing
+	}
+
+	var claims delegatedClaims
+	if _, err := parser.ParseWithClaims(strings.TrimSpace(header[7:]), &claims, func(*jwt.Token) (interface{}, error) {
+		return config.Secret, nil
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDelegatedTokenInvalid, err)
+	}
+	if claims.ExpiresAt == nil || claims.Subject == "" || claims.TenantID == "" {
+		return nil, fmt.Errorf("%w: claims obrigatórias em falta", ErrDelegatedTokenInvalid)
+	}
+
+	return &Delegation{
+		TokenID:   claims.ID,
+		Subject:   claims.Subject,
+		TenantID:  claims.TenantID,
+		Username:  claims.Username,
+		Market:    claims.Market,
+		Scopes:    strings.Fields(claims.Scope),
+		Actor:     claims.Actor,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// checkDelegation aplica os escopos exigidos e as restrições da cadeia de delegação
+func checkDelegation(config DelegatedTokenConfig, delegation *Delegation) error {
+	if delegation.Actor == nil {
+		if !config.AllowDirectTokens {
+			return fmt.Errorf("%w: o token não é delegado", ErrDelegationNotAllowed)
+		}
+	} else {
+		if len(config.AllowedActors) > 0 && !containsValue(config.AllowedActors, delegation.Actor.Identifier()) {
+			return fmt.Errorf("%w: serviço %s não aceite", ErrDelegationNotAllowed, delegation.Actor.Identifier())
+		}
+		if depth := len(delegation.ActorChain()); config.MaxDelegationDepth > 0 && depth > config.MaxDelegationDepth {
+			return fmt.Errorf("%w: %d atores, máximo %d", ErrDelegationNotAllowed, depth, config.MaxDelegationDepth)
+		}
+	}
+
+	for _, scope := range config.RequiredScopes {
+		if !delegation.HasScope(scope) {
+			return fmt.Errorf("escopo %s não concedido", scope)
+		}
+	}
+	return nil
+}
+
+// respondDelegationError responde com o erro do RFC 6750 no cabeçalho WWW-Authenticate e no corpo
+func respondDelegationError(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error=%q, error_description=%q`, code, err.Error()))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": err.Error(),
+	})
+}
+
+// isSkippedPath indica se o caminho é um dos caminhos não verificados ou um dos seus subcaminhos
+// A comparação respeita os segmentos do caminho: /health não abrange /healthz nem /health-admin
+func isSkippedPath(path string, skipPaths []string) bool {
+	for _, skip := range skipPaths {
+		if skip == "" {
+			continue
+		}
+		if path == skip || strings.HasPrefix(path, strings.TrimSuffix(skip, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsValue indica se o valor está na lista
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/innovabiz/iam/services/identity-service/middleware"
+)
+
+var delegatedTestSecret = []byte("delegated-test-secret")
+
+// delegatedToken assina um token como o emitido pela troca de tokens do identity-service
+func delegatedToken(t *testing.T, secret []byte, audience, scope string, act map[string]interface{}, ttl time.Duration) string {
+	claims := jwt.MapClaims{
+		"iss":   "innovabiz-iam",
+		"sub":   "9c1f1f0e-4b1e-4a53-9d43-1c1a0f1e2d3c",
+		"aud":   []string{audience},
+		"tid":   "5b0e3a8e-6f5e-4d0a-8f5e-3b1f2a9c7d11",
+		"scope": scope,
+		"exp":   time.Now().Add(ttl).Unix(),
+		"iat":   time.Now().Unix(),
+		"jti":   "token-1",
+	}
+	if act != nil {
+		claims["act"] = act
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+// delegatedHandler monta o middleware sobre um handler que devolve a delegação do pedido
+func delegatedHandler(t *testing.T, config middleware.DelegatedTokenConfig, extra ...func(http.Handler) http.Handler) http.Handler {
+	mw, err := middleware.DelegatedTokenMiddleware(config)
+	require.NoError(t, err)
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delegation, ok := middleware.DelegationFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(delegation)
+	})
+	for i := len(extra) - 1; i >= 0; i-- {
+		handler = extra[i](handler)
+	}
+	return mw(handler)
+}
+
+func serveDelegated(handler http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDelegatedTokenMiddlewareAcceptsDelegatedToken(t *testing.T) {
+	config := middleware.DefaultDelegatedTokenConfig("credit-bureau", delegatedTestSecret)
+	config.Issuer = "innovabiz-iam"
+	config.RequiredScopes = []string{"bureau:read"}
+	config.AllowedActors = []string{"payment-gateway"}
+	handler := delegatedHandler(t, config)
+
+	token := delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read bureau:score",
+		map[string]interface{}{"sub": "svc-payment-gateway", "client_id": "payment-gateway"}, 5*time.Minute)
+	rec := serveDelegated(handler, "/api/v1/reports", token)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var delegation middleware.Delegation
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &delegation))
+	assert.Equal(t, "5b0e3a8e-6f5e-4d0a-8f5e-3b1f2a9c7d11", delegation.TenantID)
+	assert.Equal(t, []string{"bureau:read", "bureau:score"}, delegation.Scopes)
+	assert.Equal(t, []string{"payment-gateway"}, delegation.ActorChain())
+}
+
+func TestDelegatedTokenMiddlewareRejections(t *testing.T) {
+	config := middleware.DefaultDelegatedTokenConfig("credit-bureau", delegatedTestSecret)
+	config.RequiredScopes = []string{"bureau:read"}
+	config.AllowedActors = []string{"payment-gateway"}
+	config.MaxDelegationDepth = 1
+	handler := delegatedHandler(t, config)
+
+	gateway := map[string]interface{}{"sub": "svc-payment-gateway", "client_id": "payment-gateway"}
+	tests := []struct {
+		name   string
+		token  string
+		status int
+		code   string
+	}{
+		{"sem token", "", http.StatusUnauthorized, "invalid_token"},
+		{"assinatura inválida", delegatedToken(t, []byte("other"), "credit-bureau", "bureau:read", gateway, time.Minute), http.StatusUnauthorized, "invalid_token"},
+		{"outra audiência", delegatedToken(t, delegatedTestSecret, "ledger", "bureau:read", gateway, time.Minute), http.StatusUnauthorized, "invalid_token"},
+		{"expirado", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read", gateway, -time.Hour), http.StatusUnauthorized, "invalid_token"},
+		{"sem delegação", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read", nil, time.Minute), http.StatusForbidden, "insufficient_scope"},
+		{"escopo em falta", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:score", gateway, time.Minute), http.StatusForbidden, "insufficient_scope"},
+		{"serviço não aceite", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read",
+			map[string]interface{}{"sub": "svc-marketing", "client_id": "marketing"}, time.Minute), http.StatusForbidden, "insufficient_scope"},
+		{"cadeia demasiado longa", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read",
+			map[string]interface{}{"client_id": "payment-gateway", "act": map[string]interface{}{"client_id": "checkout"}}, time.Minute),
+			http.StatusForbidden, "insufficient_scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveDelegated(handler, "/api/v1/reports", tt.token)
+			assert.Equal(t, tt.status, rec.Code)
+			assert.True(t, strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), `Bearer error="`+tt.code+`"`))
+
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body["error"])
+		})
+	}
+}
+
+func TestDelegatedTokenMiddlewareSkipPathsAndDirectTokens(t *testing.T) {
+	config := middleware.DefaultDelegatedTokenConfig("credit-bureau", delegatedTestSecret)
+	config.AllowDirectTokens = true
+	handler := delegatedHandler(t, config)
+
+	assert.Equal(t, http.StatusNoContent, serveDelegated(handler, "/health", "").Code)
+
+	token := delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read", nil, time.Minute)
+	assert.Equal(t, http.StatusOK, serveDelegated(handler, "/api/v1/reports", token).Code)
+}
+
+func TestDelegatedTokenMiddlewareSkipPathsMatchSegments(t *testing.T) {
+	config := middleware.DefaultDelegatedTokenConfig("credit-bureau", delegatedTestSecret)
+	config.SkipPaths = append(config.SkipPaths, "/docs/", "")
+	handler := delegatedHandler(t, config)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/health", http.StatusNoContent},
+		{"/health/live", http.StatusNoContent},
+		{"/metrics", http.StatusNoContent},
+		{"/docs/openapi.json", http.StatusNoContent},
+		// Caminhos que apenas começam pelo texto de uma entrada continuam a exigir token
+		{"/healthz", http.StatusUnauthorized},
+		{"/health-admin", http.StatusUnauthorized},
+		{"/readyz/reports", http.StatusUnauthorized},
+		{"/metrics-export", http.StatusUnauthorized},
+		{"/docs", http.StatusUnauthorized},
+		{"/api/v1/reports", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.code, serveDelegated(handler, tt.path, "").Code)
+		})
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	config := middleware.DefaultDelegatedTokenConfig("credit-bureau", delegatedTestSecret)
+	handler := delegatedHandler(t, config, middleware.RequireScopes("bureau:write"))
+
+	gateway := map[string]interface{}{"sub": "svc-payment-gateway", "client_id": "payment-gateway"}
+	rec := serveDelegated(handler, "/api/v1/reports", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read", gateway, time.Minute))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveDelegated(handler, "/api/v1/reports", delegatedToken(t, delegatedTestSecret, "credit-bureau", "bureau:read bureau:write", gateway, time.Minute))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestDelegatedTokenMiddlewareRequiresConfiguration(t *testing.T) {
+	_, err := middleware.DelegatedTokenMiddleware(middleware.DelegatedTokenConfig{Audience: "credit-bureau"})
+	assert.Error(t, err)
+
+	_, err = middleware.DelegatedTokenMiddleware(middleware.DelegatedTokenConfig{Secret: delegatedTestSecret})
+	assert.Error(t, err)
+}
@@ -16,22 +16,31 @@ import future.keywords.contains
 # Regra principal de decisão
 default allow = false
 
-# Super administrador tem acesso completo
+# O acesso é concedido por uma das funções do usuário, dentro dos limites de permissões
 allow {
+    some role in role_grant
+    within_permission_boundaries(role)
+}
+
+# Funções do usuário que concedem o acesso, antes da aplicação dos limites de permissões
+
+# Super administrador tem acesso completo
+role_grant["system_admin"] {
     "system_admin" in input.user.roles
 }
 
 # Administrador do tenant tem acesso às operações dentro de seu próprio tenant
-allow {
+role_grant["tenant_admin"] {
     "tenant_admin" in input.user.roles
     input.tenant.id == tenant_id_from_path
     not is_system_critical_operation
 }
 
 # Permissões baseadas em papéis e recursos
-allow {
-    # Verifica se o usuário tem as permissões necessárias
-    has_permission(input.user, required_permission)
+role_grant[role] {
+    # Verifica se a função tem a permissão necessária
+    some role in input.user.roles
+    role_has_permission(role, required_permission)
     
     # Verifica se a operação está dentro do escopo do tenant
     tenant_scope_valid
@@ -40,6 +49,15 @@ allow {
     not is_restricted_operation
 }
 
+# Uma função concedia o acesso, mas os limites de permissões recusaram-no
+# O middleware regista a recusa como incidente de segurança de severidade alta
+default boundary_violation = false
+
+boundary_violation {
+    count(role_grant) > 0
+    not allow
+}
+
 # Determina a permissão necessária com base no caminho e método
 required_permission = permission {
     # Mapeamento de rotas para permissões
@@ -99,6 +117,12 @@ has_permission(_, _) {
     not role_permissions
 }
 
+# Verifica se a função tem a permissão
+role_has_permission(role, permission) {
+    some perm in role_permissions[role]
+    perm = permission
+}
+
 # Definição temporária de permissões para funções (em produção, isso seria buscado de uma fonte dinâmica)
 role_permissions = {
     "system_admin": ["*:*"],
@@ -120,6 +144,57 @@ role_permissions = {
     ]
 }
 
+# Limites de permissões (permission boundaries)
+# input.user.permission_boundaries.user contém os padrões do limite do usuário como administrador
+# delegado, ausente quando o usuário não tem limite; input.user.permission_boundaries.roles contém
+# os padrões das funções do usuário que têm limite. Sem limites no tenant, o campo é omitido.
+
+# Permissão verificada contra os limites; sem mapeamento da rota, só o padrão "*" a abrange
+boundary_permission = required_permission
+
+boundary_permission = "*:*" {
+    not required_permission
+}
+
+# A função e o usuário só exercem as permissões dentro dos seus limites
+within_permission_boundaries(role) {
+    within_user_boundary
+    within_role_boundary(role)
+}
+
+within_user_boundary {
+    not is_array(input.user.permission_boundaries.user)
+}
+
+within_user_boundary {
+    some pattern in input.user.permission_boundaries.user
+    boundary_pattern_matches(pattern, boundary_permission)
+}
+
+within_role_boundary(role) {
+    not input.user.permission_boundaries.roles[role]
+}
+
+within_role_boundary(role) {
+    some pattern in input.user.permission_boundaries.roles[role]
+    boundary_pattern_matches(pattern, boundary_permission)
+}
+
+# Correspondência dos padrões dos limites: "*", o código exato ou um prefixo terminado em ":*"
+# A mesma correspondência é aplicada nas atribuições (model.PermissionPatternMatches)
+boundary_pattern_matches(pattern, _) {
+    pattern == "*"
+}
+
+boundary_pattern_matches(pattern, permission) {
+    pattern == permission
+}
+
+boundary_pattern_matches(pattern, permission) {
+    endswith(pattern, ":*")
+    startswith(permission, trim_suffix(pattern, "*"))
+}
+
 # Extrai o tenant_id do caminho, se disponível
 tenant_id_from_path = tenant_id {
     regex.match("/api/v1/tenants/([^/]+)/", input.request.path)
@@ -10,6 +10,8 @@ package adapters
 import (
 	"context"
 	"time"
+
+	"github.com/innovabiz/iam/resilience"
 )
 
 // CreditReportRequest representa uma solicitação de relatório de crédito
@@ -145,6 +147,27 @@ type CreditProviderConfig struct {
 	RetryCount        int               `json:"retryCount"`
 	RetryInterval     time.Duration     `json:"retryInterval"`
 	AdditionalConfig  map[string]string `json:"additionalConfig,omitempty"`
+	
+	// Resilience sobrepõe TimeoutSeconds, RetryCount e RetryInterval com a política partilhada
+	Resilience        *resilience.Policy `json:"resilience,omitempty"`
+}
+
+// ResiliencePolicy retorna a política das chamadas ao provedor: os campos legados
+// (TimeoutSeconds, RetryCount, RetryInterval) e depois a política configurada
+func (c CreditProviderConfig) ResiliencePolicy() resilience.Policy {
+	legacy := resilience.Policy{
+		Timeout:        time.Duration(c.TimeoutSeconds) * time.Second,
+		InitialBackoff: c.RetryInterval,
+	}
+	if c.RetryCount > 0 {
+		legacy.MaxAttempts = c.RetryCount + 1
+	}
+	
+	policy := resilience.DefaultPolicy().Merge(legacy)
+	if c.Resilience != nil {
+		policy = policy.Merge(*c.Resilience)
+	}
+	return policy
 }
 
 // CreditProvider define a interface para adaptadores de provedores de crédito
@@ -11,11 +11,14 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+
+	"github.com/innovabiz/iam/resilience"
 )
 
 // DefaultCreditProviderFactory é a implementação padrão da fábrica de provedores
 type DefaultCreditProviderFactory struct {
 	registeredProviders map[string]func() CreditProvider
+	policies            *resilience.Policies
 	mutex               sync.RWMutex
 }
 
@@ -47,6 +50,15 @@ func (f *DefaultCreditProviderFactory) RegisterProvider(
 	return nil
 }
 
+// SetResiliencePolicies define as políticas de resiliência por provedor; a política do
+// tipo de provedor é usada quando existe e a configuração não traz a sua própria
+func (f *DefaultCreditProviderFactory) SetResiliencePolicies(policies *resilience.Policies) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	
+	f.policies = policies
+}
+
 // CreateProvider cria uma nova instância de um provedor com as configurações fornecidas
 func (f *DefaultCreditProviderFactory) CreateProvider(
 	providerType string,
@@ -54,12 +66,18 @@ func (f *DefaultCreditProviderFactory) CreateProvider(
 ) (CreditProvider, error) {
 	f.mutex.RLock()
 	createFn, exists := f.registeredProviders[providerType]
+	policies := f.policies
 	f.mutex.RUnlock()
 	
 	if !exists {
 		return nil, fmt.Errorf("provedor do tipo '%s' não encontrado", providerType)
 	}
 	
+	if config.Resilience == nil && policies.Has(providerType) {
+		policy := policies.For(providerType)
+		config.Resilience = &policy
+	}
+	
 	provider := createFn()
 	if err := provider.Initialize(config); err != nil {
 		return nil, fmt.Errorf("erro ao inicializar provedor '%s': %w", providerType, err)
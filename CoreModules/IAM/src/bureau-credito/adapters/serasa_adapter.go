@@ -15,11 +15,16 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/innovabiz/iam/resilience"
+
+	"innovabiz/iam/src/bureau-credito/telemetry"
 )
 
 // SerasaAdapter implementa CreditProvider para integração com Serasa
 type SerasaAdapter struct {
 	config      CreditProviderConfig
+	policy      resilience.Policy
 	httpClient  *http.Client
 	initialized bool
 }
@@ -54,9 +59,12 @@ type SerasaCreditReport struct {
 
 // NewSerasaAdapter cria um novo adaptador para Serasa
 func NewSerasaAdapter() *SerasaAdapter {
+	policy := resilience.DefaultPolicy()
 	return &SerasaAdapter{
+		policy: policy,
 		httpClient: &http.Client{
-			Timeout: time.Second * 30,
+			Timeout:   policy.Timeout,
+			Transport: telemetry.NewTransport(nil), // Propaga trace e baggage da consulta
 		},
 		initialized: false,
 	}
@@ -73,7 +81,10 @@ func (s *SerasaAdapter) Initialize(config CreditProviderConfig) error {
 	}
 	
 	s.config = config
-	s.httpClient.Timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	s.policy = config.ResiliencePolicy()
+	// A consulta é uma leitura, apesar do POST, e pode ser repetida
+	s.policy.RetryNonIdempotent = true
+	s.httpClient.Timeout = s.policy.Timeout
 	s.initialized = true
 	
 	return nil
@@ -166,14 +177,18 @@ func (s *SerasaAdapter) IsHealthy(ctx context.Context) bool {
 	}
 	
 	url := fmt.Sprintf("%s/health", s.config.BaseURL)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return false
-	}
-	
-	s.addAuthHeaders(req)
 	
-	resp, err := s.httpClient.Do(req)
+	// A verificação de saúde não repete: uma falha deve ser reportada de imediato
+	policy := s.policy
+	policy.MaxAttempts = 1
+	resp, err := resilience.DoHTTP(ctx, s.httpClient, policy, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.addAuthHeaders(req)
+		return req, nil
+	})
 	if err != nil {
 		return false
 	}
@@ -212,23 +227,20 @@ func (s *SerasaAdapter) fetchSerasaReport(ctx context.Context, endpoint string,
 		return nil, fmt.Errorf("erro ao serializar payload: %w", err)
 	}
 	
-	// Preparar requisição HTTP
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		endpoint,
-		strings.NewReader(string(jsonPayload)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
-	}
-	
-	// Adicionar headers de autenticação e conteúdo
-	req.Header.Set("Content-Type", "application/json")
-	s.addAuthHeaders(req)
-	
-	// Executar requisição
-	resp, err := s.httpClient.Do(req)
+	// Executar requisição com retry, orçamento de prazo e hedging da política do provedor
+	resp, err := resilience.Hedge(ctx, s.policy, func(ctx context.Context) (*http.Response, error) {
+		return resilience.DoHTTP(ctx, s.httpClient, s.policy, func(ctx context.Context) (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(string(jsonPayload)))
+			if err != nil {
+				return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+			}
+			
+			// Adicionar headers de autenticação e conteúdo
+			req.Header.Set("Content-Type", "application/json")
+			s.addAuthHeaders(req)
+			return req, nil
+		})
+	})
 	if err != nil {
 		return nil, fmt.Errorf("erro na requisição HTTP: %w", err)
 	}
@@ -10,8 +10,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -21,8 +24,12 @@ import (
 	
 	"innovabiz/iam/src/bureau-credito/orchestration"
 	"innovabiz/iam/src/bureau-credito/orchestration/models"
+	"innovabiz/iam/src/bureau-credito/residency"
 )
 
+// Tamanho máximo de uma consulta encaminhada por outra região
+const maxForwardedAssessmentBytes = 1 << 20
+
 // BureauController gerencia as operações da API REST do Bureau de Crédito
 type BureauController struct {
 	orchestrator     *orchestration.BureauOrchestrator
@@ -45,6 +52,18 @@ func (c *BureauController) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/assessments/{id}", c.GetAssessment).Methods("GET")
 	router.HandleFunc("/assessments/{id}/status", c.GetAssessmentStatus).Methods("GET")
 	
+	// Rotas para o SLA dos provedores de crédito
+	router.HandleFunc("/providers/sla", c.GetProvidersSLAStatus).Methods("GET")
+	router.HandleFunc("/providers/{id}/sla", c.GetProviderSLAStatus).Methods("GET")
+	
+	// Rotas para o roteamento por custo dos provedores de crédito
+	router.HandleFunc("/tenants/{tenantId}/routing/decisions", c.GetRoutingDecisions).Methods("GET")
+	router.HandleFunc("/tenants/{tenantId}/routing/spend", c.GetTenantSpend).Methods("GET")
+	
+	// Rotas para a residência dos dados entre mercados
+	router.HandleFunc(residency.ForwardPath, c.ReceiveForwardedAssessment).Methods("POST")
+	router.HandleFunc("/tenants/{tenantId}/residency/decisions", c.GetResidencyDecisions).Methods("GET")
+	
 	// Rotas para health check e informações
 	router.HandleFunc("/health", c.HealthCheck).Methods("GET")
 	router.HandleFunc("/info", c.GetInfo).Methods("GET")
@@ -100,7 +119,7 @@ func (c *BureauController) CreateAssessment(w http.ResponseWriter, r *http.Reque
 			Msg("Erro ao processar solicitação de avaliação")
 			
 		// Responder com erro
-		c.respondWithError(w, http.StatusInternalServerError, "Erro ao processar solicitação de avaliação", err)
+		c.respondWithAssessmentError(w, err)
 		return
 	}
 	
@@ -277,6 +296,195 @@ func (c *BureauController) GetAssessmentStatus(w http.ResponseWriter, r *http.Re
 	c.respondWithJSON(w, http.StatusOK, statusResponse)
 }
 
+// GetProvidersSLAStatus retorna o nível e o cumprimento do SLO de todos os provedores monitorizados
+func (c *BureauController) GetProvidersSLAStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := c.orchestrator.GetProviderSLAStatus()
+	if err != nil {
+		c.respondWithSLAError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, struct {
+		Providers interface{} `json:"providers"`
+	}{Providers: statuses})
+}
+
+// GetProviderSLAStatus retorna o nível e o cumprimento do SLO de um provedor
+func (c *BureauController) GetProviderSLAStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		c.respondWithError(w, http.StatusBadRequest, "ID do provedor não fornecido", nil)
+		return
+	}
+	
+	status, err := c.orchestrator.GetProviderSLAStatusByID(id)
+	if err != nil {
+		c.respondWithSLAError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, status)
+}
+
+// respondWithSLAError converte os erros da monitorização de SLA em respostas HTTP
+func (c *BureauController) respondWithSLAError(w http.ResponseWriter, err error) {
+	if errors.Is(err, orchestration.ErrSLAMonitorDisabled) {
+		c.respondWithError(w, http.StatusNotImplemented, "Monitorização de SLA não configurada", err)
+		return
+	}
+	c.respondWithError(w, http.StatusInternalServerError, "Erro ao obter SLA dos provedores", err)
+}
+
+// GetRoutingDecisions retorna a auditoria das decisões de roteamento por custo do tenant
+func (c *BureauController) GetRoutingDecisions(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenantId"]
+	if tenantID == "" {
+		c.respondWithError(w, http.StatusBadRequest, "ID do tenant não fornecido", nil)
+		return
+	}
+	
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.respondWithError(w, http.StatusBadRequest, "Limite inválido", err)
+			return
+		}
+		limit = parsed
+	}
+	
+	decisions, err := c.orchestrator.GetRoutingDecisions(r.Context(), tenantID, limit)
+	if err != nil {
+		c.respondWithRoutingError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, struct {
+		Decisions interface{} `json:"decisions"`
+	}{Decisions: decisions})
+}
+
+// GetTenantSpend retorna o gasto do tenant em consultas no mês corrente e o orçamento restante
+func (c *BureauController) GetTenantSpend(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenantId"]
+	if tenantID == "" {
+		c.respondWithError(w, http.StatusBadRequest, "ID do tenant não fornecido", nil)
+		return
+	}
+	
+	spend, err := c.orchestrator.GetTenantSpend(tenantID)
+	if err != nil {
+		c.respondWithRoutingError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, spend)
+}
+
+// respondWithRoutingError converte os erros do roteamento por custo em respostas HTTP
+func (c *BureauController) respondWithRoutingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, orchestration.ErrCostRoutingDisabled) {
+		c.respondWithError(w, http.StatusNotImplemented, "Roteamento por custo não configurado", err)
+		return
+	}
+	c.respondWithError(w, http.StatusInternalServerError, "Erro ao obter o roteamento por custo", err)
+}
+
+// ReceiveForwardedAssessment processa uma consulta encaminhada pela instância de outra região
+// A assinatura da região de origem é verificada antes de a consulta ser interpretada
+func (c *BureauController) ReceiveForwardedAssessment(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxForwardedAssessmentBytes))
+	if err != nil {
+		c.respondWithError(w, http.StatusBadRequest, "Erro ao ler consulta encaminhada", err)
+		return
+	}
+	
+	from := r.Header.Get(residency.ForwardedFromHeader)
+	if err := c.orchestrator.VerifyForwardedAssessment(from, r.Header.Get(residency.SignatureHeader), body); err != nil {
+		c.respondWithResidencyError(w, err)
+		return
+	}
+	
+	var request models.AssessmentRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		c.respondWithError(w, http.StatusBadRequest, "Erro ao decodificar consulta encaminhada", err)
+		return
+	}
+	
+	log.Info().
+		Str("requestId", request.RequestID).
+		Str("tenantId", request.TenantID).
+		Str("forwardedFrom", from).
+		Str("residencyDecision", r.Header.Get(residency.DecisionHeader)).
+		Msg("Consulta encaminhada por outra região recebida")
+	
+	ctx := residency.WithForwardedFrom(r.Context(), from)
+	response, err := c.orchestrator.RequestAssessment(ctx, request)
+	if err != nil {
+		c.respondWithAssessmentError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetResidencyDecisions retorna a auditoria das decisões de residência entre regiões do tenant
+func (c *BureauController) GetResidencyDecisions(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenantId"]
+	if tenantID == "" {
+		c.respondWithError(w, http.StatusBadRequest, "ID do tenant não fornecido", nil)
+		return
+	}
+	
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.respondWithError(w, http.StatusBadRequest, "Limite inválido", err)
+			return
+		}
+		limit = parsed
+	}
+	
+	decisions, err := c.orchestrator.GetResidencyDecisions(r.Context(), tenantID, limit)
+	if err != nil {
+		c.respondWithResidencyError(w, err)
+		return
+	}
+	
+	c.respondWithJSON(w, http.StatusOK, struct {
+		Decisions interface{} `json:"decisions"`
+	}{Decisions: decisions})
+}
+
+// respondWithAssessmentError converte os erros do processamento de uma avaliação em respostas HTTP
+func (c *BureauController) respondWithAssessmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, residency.ErrTransferProhibited), errors.Is(err, residency.ErrRegionUnavailable),
+		errors.Is(err, residency.ErrForwardingLoop), errors.Is(err, residency.ErrRegionalInstance):
+		c.respondWithResidencyError(w, err)
+	default:
+		c.respondWithError(w, http.StatusInternalServerError, "Erro ao processar solicitação de avaliação", err)
+	}
+}
+
+// respondWithResidencyError converte os erros da residência dos dados em respostas HTTP
+func (c *BureauController) respondWithResidencyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, orchestration.ErrResidencyRoutingDisabled):
+		c.respondWithError(w, http.StatusNotImplemented, "Roteamento de residência não configurado", err)
+	case errors.Is(err, residency.ErrForwardUnauthorized):
+		c.respondWithError(w, http.StatusUnauthorized, "Consulta encaminhada não autorizada", err)
+	case errors.Is(err, residency.ErrTransferProhibited), errors.Is(err, residency.ErrForwardingLoop):
+		c.respondWithError(w, http.StatusUnavailableForLegalReasons, "Consulta recusada pela residência dos dados", err)
+	case errors.Is(err, residency.ErrRegionUnavailable), errors.Is(err, residency.ErrRegionalInstance):
+		c.respondWithError(w, http.StatusBadGateway, "Instância regional do mercado indisponível", err)
+	default:
+		c.respondWithError(w, http.StatusInternalServerError, "Erro na residência dos dados", err)
+	}
+}
+
 // HealthCheck verifica se o serviço está operacional
 func (c *BureauController) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Verificar se o orquestrador está operacional
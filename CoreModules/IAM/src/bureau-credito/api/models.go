@@ -19,10 +19,13 @@ type AssessmentRequest struct {
 	UserID        string            `json:"userId" validate:"required" example:"user-123456"`
 	TenantID      string            `json:"tenantId" validate:"required" example:"tenant-789012"`
 	CorrelationID string            `json:"correlationId,omitempty" example:"corr-abcdef123456"`
+	Market        string            `json:"market,omitempty" example:"brazil"`
 	
 	// Configuração de avaliação
 	AssessmentTypes  []string        `json:"assessmentTypes" validate:"required,dive,oneof=IDENTITY CREDIT FRAUD COMPLIANCE RISK COMPREHENSIVE" example:"FRAUD,CREDIT"`
 	CreditProviders  []string        `json:"creditProviders,omitempty" example:"SERASA,SPC"`
+	TipoConsulta     string          `json:"tipoConsulta,omitempty" example:"score"`
+	RequiredFields   []string        `json:"requiredFields,omitempty" example:"score,restricoes"`
 	IdentityProviders []string       `json:"identityProviders,omitempty" example:"SERASA,SERPRO"`
 	ComplianceRules  []string        `json:"complianceRules,omitempty" example:"AML,KYC,FATCA"`
 	
@@ -230,9 +233,12 @@ func (req *AssessmentRequest) ToInternalModel() *models.AssessmentRequest {
 		UserID:          req.UserID,
 		TenantID:        req.TenantID,
 		CorrelationID:   req.CorrelationID,
+		Market:          req.Market,
 		RequestTimestamp: time.Now(),
 		AssessmentTypes: assessmentTypes,
 		CreditProviders: req.CreditProviders,
+		TipoConsulta:    req.TipoConsulta,
+		RequiredFields:  req.RequiredFields,
 		IdentityProviders: req.IdentityProviders,
 		ComplianceRules: req.ComplianceRules,
 		ForceRefresh:    req.ForceRefresh,
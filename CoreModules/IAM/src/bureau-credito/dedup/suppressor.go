@@ -0,0 +1,262 @@
+/**
+ * @file suppressor.go
+ * @description Supressão de consultas duplicadas ao Bureau de Crédito numa janela configurável
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Origem do resultado devolvido a uma consulta
+const (
+	OutcomeExecuted = "executed" // Consulta enviada ao provedor
+	OutcomeInFlight = "inflight" // Partilhou uma consulta idêntica ainda em curso
+	OutcomeRecent   = "recent"   // Reutilizou o resultado recente de uma consulta idêntica
+	OutcomeBypassed = "bypassed" // Supressão desativada para o tenant
+)
+
+// Valores padrão da supressão
+const (
+	DefaultWindow     = 30 * time.Second
+	DefaultMaxEntries = 10000
+)
+
+// Config define a supressão de consultas duplicadas
+type Config struct {
+	// Window é o período durante o qual o resultado de uma consulta é reutilizado; zero desativa a reutilização
+	Window time.Duration `json:"window"`
+
+	// MaxEntries limita os resultados recentes mantidos em memória
+	MaxEntries int `json:"maxEntries"`
+
+	// OptOutTenants lista os tenants cujas consultas são sempre enviadas ao provedor
+	OptOutTenants []string `json:"optOutTenants,omitempty"`
+}
+
+// DefaultConfig retorna a configuração padrão da supressão
+func DefaultConfig() Config {
+	return Config{
+		Window:     DefaultWindow,
+		MaxEntries: DefaultMaxEntries,
+	}
+}
+
+// Key identifica consultas idênticas de um tenant
+type Key struct {
+	TenantID   string
+	Documento  string
+	Tipo       string
+	Finalidade string
+}
+
+// hash resume a chave sem manter o documento em claro na memória
+func (k Key) hash() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		k.TenantID, k.Tipo, k.Finalidade, strings.TrimSpace(k.Documento),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Result é o resultado de uma consulta, executada ou suprimida
+type Result struct {
+	Value       interface{} // Resultado partilhado; não deve ser alterado pelo chamador
+	Outcome     string
+	CompletedAt time.Time // Momento em que a consulta original terminou
+}
+
+// Suppressed indica se a consulta foi respondida sem chamar o provedor
+func (r Result) Suppressed() bool {
+	return r.Outcome == OutcomeInFlight || r.Outcome == OutcomeRecent
+}
+
+// Call executa a consulta no provedor
+type Call func(ctx context.Context) (interface{}, error)
+
+// call é uma consulta em curso partilhada pelas duplicadas
+type call struct {
+	done        chan struct{}
+	value       interface{}
+	err         error
+	completedAt time.Time
+}
+
+// entry é o resultado recente de uma consulta concluída com sucesso
+type entry struct {
+	value       interface{}
+	completedAt time.Time
+}
+
+// Suppressor devolve às consultas idênticas (mesmo tenant, documento, tipo e finalidade)
+// o resultado da consulta em curso ou da mais recente dentro da janela
+type Suppressor struct {
+	config  Config
+	metrics *Metrics
+	now     func() time.Time
+
+	mu       sync.Mutex
+	optOut   map[string]bool
+	inflight map[string]*call
+	recent   map[string]entry
+}
+
+// NewSuppressor cria o supressor de consultas duplicadas
+func NewSuppressor(config Config) *Suppressor {
+	if config.Window < 0 {
+		config.Window = 0
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultMaxEntries
+	}
+	optOut := make(map[string]bool, len(config.OptOutTenants))
+	for _, tenantID := range config.OptOutTenants {
+		optOut[tenantID] = true
+	}
+	return &Suppressor{
+		config:   config,
+		now:      time.Now,
+		optOut:   optOut,
+		inflight: make(map[string]*call),
+		recent:   make(map[string]entry),
+	}
+}
+
+// SetClock substitui o relógio usado na janela de supressão
+func (s *Suppressor) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetMetrics define as métricas Prometheus das consultas suprimidas
+func (s *Suppressor) SetMetrics(metrics *Metrics) {
+	s.metrics = metrics
+}
+
+// SetTenantOptOut ativa (true) ou retira a exclusão de um tenant da supressão
+func (s *Suppressor) SetTenantOptOut(tenantID string, optOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if optOut {
+		s.optOut[tenantID] = true
+		return
+	}
+	delete(s.optOut, tenantID)
+}
+
+// Do executa a consulta ou, havendo uma idêntica em curso ou concluída dentro da janela,
+// devolve o seu resultado. As duplicadas de uma consulta em curso partilham também o seu erro;
+// após a conclusão, apenas resultados bem-sucedidos são reutilizados
+func (s *Suppressor) Do(ctx context.Context, key Key, fn Call) (Result, error) {
+	s.mu.Lock()
+	if s.optOut[key.TenantID] {
+		s.mu.Unlock()
+		s.metrics.observe(key, OutcomeBypassed)
+		value, err := fn(ctx)
+		return Result{Value: value, Outcome: OutcomeBypassed, CompletedAt: s.now()}, err
+	}
+
+	id := key.hash()
+	now := s.now()
+	if cached, exists := s.recent[id]; exists {
+		if now.Sub(cached.completedAt) < s.config.Window {
+			s.mu.Unlock()
+			s.metrics.observe(key, OutcomeRecent)
+			return Result{Value: cached.value, Outcome: OutcomeRecent, CompletedAt: cached.completedAt}, nil
+		}
+		delete(s.recent, id)
+	}
+
+	if pending, exists := s.inflight[id]; exists {
+		s.mu.Unlock()
+		s.metrics.observe(key, OutcomeInFlight)
+		select {
+		case <-pending.done:
+			return Result{Value: pending.value, Outcome: OutcomeInFlight, CompletedAt: pending.completedAt}, pending.err
+		case <-ctx.Done():
+			return Result{Outcome: OutcomeInFlight}, ctx.Err()
+		}
+	}
+
+	pending := &call{done: make(chan struct{})}
+	s.inflight[id] = pending
+	s.mu.Unlock()
+	s.metrics.observe(key, OutcomeExecuted)
+
+	pending.value, pending.err = fn(ctx)
+	pending.completedAt = s.now()
+
+	s.mu.Lock()
+	delete(s.inflight, id)
+	if pending.err == nil && s.config.Window > 0 {
+		s.store(id, entry{value: pending.value, completedAt: pending.completedAt})
+	}
+	s.mu.Unlock()
+	close(pending.done)
+
+	return Result{Value: pending.value, Outcome: OutcomeExecuted, CompletedAt: pending.completedAt}, pending.err
+}
+
+// Forget descarta o resultado recente da consulta, forçando a próxima a chamar o provedor
+func (s *Suppressor) Forget(key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.recent, key.hash())
+}
+
+// store guarda o resultado recente, descartando os expirados quando o limite é atingido
+// Sem espaço após a limpeza, o resultado não é guardado
+func (s *Suppressor) store(id string, value entry) {
+	if len(s.recent) >= s.config.MaxEntries {
+		cutoff := value.completedAt.Add(-s.config.Window)
+		for key, cached := range s.recent {
+			if !cached.completedAt.After(cutoff) {
+				delete(s.recent, key)
+			}
+		}
+		if len(s.recent) >= s.config.MaxEntries {
+			return
+		}
+	}
+	s.recent[id] = value
+}
+
+// Metrics contém as métricas Prometheus da supressão de consultas duplicadas
+type Metrics struct {
+	consultasCounter *prometheus.CounterVec
+}
+
+// NewMetrics cria e regista as métricas de supressão
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		// Consultas poupadas = soma de outcome="inflight" e outcome="recent"
+		consultasCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_dedup_consultas_total",
+				Help: "Número total de consultas pela origem do resultado (executed, inflight, recent, bypassed)",
+			},
+			[]string{"tipo", "finalidade", "outcome"},
+		),
+	}
+
+	registry.MustRegister(m.consultasCounter)
+	return m
+}
+
+// observe regista a origem do resultado de uma consulta
+func (m *Metrics) observe(key Key, outcome string) {
+	if m == nil {
+		return
+	}
+	m.consultasCounter.WithLabelValues(key.Tipo, key.Finalidade, outcome).Inc()
+}
@@ -0,0 +1,203 @@
+/**
+ * @file suppressor_test.go
+ * @description Testes da supressão de consultas duplicadas ao Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/dedup"
+)
+
+// testClock é um relógio ajustável para os testes da janela de supressão
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newSuppressor(config dedup.Config) (*dedup.Suppressor, *testClock) {
+	clock := &testClock{now: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}
+	suppressor := dedup.NewSuppressor(config)
+	suppressor.SetClock(clock.Now)
+	return suppressor, clock
+}
+
+var consultaKey = dedup.Key{
+	TenantID:   "tenant-1",
+	Documento:  "123.456.789-00",
+	Tipo:       "score",
+	Finalidade: "concessao_credito",
+}
+
+// countingCall conta as chamadas ao provedor e devolve o número da chamada
+func countingCall(calls *int32) dedup.Call {
+	return func(ctx context.Context) (interface{}, error) {
+		return atomic.AddInt32(calls, 1), nil
+	}
+}
+
+func TestRecentResultReusedWithinWindow(t *testing.T) {
+	suppressor, clock := newSuppressor(dedup.Config{Window: time.Minute})
+	ctx := context.Background()
+	var calls int32
+
+	first, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeExecuted, first.Outcome)
+	assert.False(t, first.Suppressed())
+
+	clock.Advance(30 * time.Second)
+	second, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeRecent, second.Outcome)
+	assert.True(t, second.Suppressed())
+	assert.Equal(t, int32(1), second.Value)
+	assert.Equal(t, first.CompletedAt, second.CompletedAt)
+
+	clock.Advance(30 * time.Second)
+	third, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeExecuted, third.Outcome)
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestDifferentConsultasNotSuppressed(t *testing.T) {
+	suppressor, _ := newSuppressor(dedup.Config{Window: time.Minute})
+	ctx := context.Background()
+	var calls int32
+
+	keys := []dedup.Key{consultaKey, consultaKey, consultaKey, consultaKey}
+	keys[1].Tipo = "completa"
+	keys[2].Finalidade = "abertura_conta"
+	keys[3].TenantID = "tenant-2"
+	for _, key := range keys {
+		result, err := suppressor.Do(ctx, key, countingCall(&calls))
+		require.NoError(t, err)
+		assert.Equal(t, dedup.OutcomeExecuted, result.Outcome)
+	}
+	assert.Equal(t, int32(len(keys)), calls)
+}
+
+func TestInFlightConsultaShared(t *testing.T) {
+	suppressor, _ := newSuppressor(dedup.Config{Window: time.Minute})
+	ctx := context.Background()
+	var calls int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	slowCall := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		return "resultado", nil
+	}
+
+	leader := make(chan dedup.Result, 1)
+	go func() {
+		result, _ := suppressor.Do(ctx, consultaKey, slowCall)
+		leader <- result
+	}()
+	<-started
+
+	const followers = 5
+	var wg sync.WaitGroup
+	results := make(chan dedup.Result, followers)
+	for i := 0; i < followers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := suppressor.Do(ctx, consultaKey, slowCall)
+			assert.NoError(t, err)
+			results <- result
+		}()
+	}
+
+	// As duplicadas aguardam a consulta em curso
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	assert.Equal(t, dedup.OutcomeExecuted, (<-leader).Outcome)
+	for result := range results {
+		assert.Equal(t, dedup.OutcomeInFlight, result.Outcome)
+		assert.Equal(t, "resultado", result.Value)
+	}
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestFailedConsultaNotReused(t *testing.T) {
+	suppressor, _ := newSuppressor(dedup.Config{Window: time.Minute})
+	ctx := context.Background()
+
+	_, err := suppressor.Do(ctx, consultaKey, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("provedor indisponível")
+	})
+	require.Error(t, err)
+
+	var calls int32
+	result, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeExecuted, result.Outcome)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestTenantOptOut(t *testing.T) {
+	suppressor, _ := newSuppressor(dedup.Config{Window: time.Minute, OptOutTenants: []string{"tenant-1"}})
+	ctx := context.Background()
+	var calls int32
+
+	for i := 0; i < 3; i++ {
+		result, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+		require.NoError(t, err)
+		assert.Equal(t, dedup.OutcomeBypassed, result.Outcome)
+	}
+	assert.Equal(t, int32(3), calls)
+
+	suppressor.SetTenantOptOut("tenant-1", false)
+	_, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	result, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeRecent, result.Outcome)
+	assert.Equal(t, int32(4), calls)
+}
+
+func TestForgetDiscardsRecentResult(t *testing.T) {
+	suppressor, _ := newSuppressor(dedup.Config{Window: time.Minute})
+	ctx := context.Background()
+	var calls int32
+
+	_, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	suppressor.Forget(consultaKey)
+
+	result, err := suppressor.Do(ctx, consultaKey, countingCall(&calls))
+	require.NoError(t, err)
+	assert.Equal(t, dedup.OutcomeExecuted, result.Outcome)
+	assert.Equal(t, int32(2), calls)
+}
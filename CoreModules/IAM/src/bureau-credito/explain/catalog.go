@@ -0,0 +1,140 @@
+/**
+ * @file catalog.go
+ * @description Catálogo dos códigos de motivo do score por mercado
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package explain
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Mercados do catálogo padrão; os nomes seguem constants.Market*
+const (
+	MarketAngola = "Angola"
+	MarketBrazil = "Brazil"
+	MarketEU     = "EU"
+	MarketUSA    = "USA"
+	MarketGlobal = "Global" // Catálogo usado quando o mercado não tem catálogo próprio
+)
+
+// Catalog guarda os códigos de motivo de cada mercado
+type Catalog struct {
+	mutex   sync.RWMutex
+	markets map[string]map[ReasonKey]ReasonCode
+}
+
+// NewCatalog cria um catálogo vazio
+func NewCatalog() *Catalog {
+	return &Catalog{markets: make(map[string]map[ReasonKey]ReasonCode)}
+}
+
+// Register define os códigos de motivo de um mercado, substituindo os existentes
+// Todos os motivos padronizados devem ter código, para que nenhum motivo fique sem explicação
+func (c *Catalog) Register(market string, codes map[ReasonKey]ReasonCode) error {
+	if market == "" {
+		return fmt.Errorf("mercado do catálogo não informado")
+	}
+	for key := range reasonFactors {
+		if codes[key].Code == "" || codes[key].Description == "" {
+			return fmt.Errorf("motivo %s sem código ou descrição no catálogo do mercado %s", key, market)
+		}
+	}
+
+	copied := make(map[ReasonKey]ReasonCode, len(codes))
+	for key, code := range codes {
+		if _, ok := reasonFactors[key]; !ok {
+			return fmt.Errorf("motivo desconhecido %s no catálogo do mercado %s", key, market)
+		}
+		copied[key] = code
+	}
+
+	c.mutex.Lock()
+	c.markets[market] = copied
+	c.mutex.Unlock()
+	return nil
+}
+
+// Lookup retorna o código do motivo no mercado, ou no catálogo global quando o mercado não tem catálogo
+func (c *Catalog) Lookup(market string, key ReasonKey) (ReasonCode, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	codes, ok := c.markets[market]
+	if !ok {
+		codes, ok = c.markets[MarketGlobal]
+	}
+	if !ok {
+		return ReasonCode{}, false
+	}
+	code, ok := codes[key]
+	return code, ok
+}
+
+// DefaultCatalog retorna o catálogo com os códigos de motivo dos mercados suportados
+func DefaultCatalog() *Catalog {
+	catalog := NewCatalog()
+	for market, codes := range defaultCodes {
+		if err := catalog.Register(market, codes); err != nil {
+			panic(err) // Catálogo padrão incompleto: erro de programação
+		}
+	}
+	return catalog
+}
+
+// defaultCodes são os códigos de motivo padrão por mercado
+var defaultCodes = map[string]map[ReasonKey]ReasonCode{
+	MarketUSA: {
+		ReasonRecentDelinquency:   {Code: "US01", Description: "Serious delinquency in the last 12 months"},
+		ReasonDelinquency:         {Code: "US02", Description: "Level of delinquency on accounts"},
+		ReasonHighUtilization:     {Code: "US03", Description: "Proportion of balances to credit limits is too high"},
+		ReasonFraudRecord:         {Code: "US04", Description: "Fraud record reported on file"},
+		ReasonPublicRecord:        {Code: "US05", Description: "Derogatory public record or tax lien filed"},
+		ReasonCollection:          {Code: "US06", Description: "Collection account or charged-off debt reported"},
+		ReasonShortHistory:        {Code: "US07", Description: "Length of time accounts have been established"},
+		ReasonInsufficientHistory: {Code: "US08", Description: "Insufficient credit history"},
+	},
+	MarketBrazil: {
+		ReasonRecentDelinquency:   {Code: "BR01", Description: "Atraso ou inadimplência nos últimos 12 meses"},
+		ReasonDelinquency:         {Code: "BR02", Description: "Histórico de pagamentos com atrasos"},
+		ReasonHighUtilization:     {Code: "BR03", Description: "Utilização elevada dos limites de crédito (Cadastro Positivo)"},
+		ReasonFraudRecord:         {Code: "BR04", Description: "Registro de fraude no documento"},
+		ReasonPublicRecord:        {Code: "BR05", Description: "Protesto em cartório ou inscrição em dívida ativa"},
+		ReasonCollection:          {Code: "BR06", Description: "Dívida em processo de recuperação de crédito"},
+		ReasonShortHistory:        {Code: "BR07", Description: "Histórico de crédito recente"},
+		ReasonInsufficientHistory: {Code: "BR08", Description: "Histórico de crédito insuficiente"},
+	},
+	MarketAngola: {
+		ReasonRecentDelinquency:   {Code: "AO01", Description: "Incumprimento nos últimos 12 meses"},
+		ReasonDelinquency:         {Code: "AO02", Description: "Histórico de pagamentos com atrasos"},
+		ReasonHighUtilization:     {Code: "AO03", Description: "Utilização elevada dos limites de crédito"},
+		ReasonFraudRecord:         {Code: "AO04", Description: "Registo de fraude na Central de Risco"},
+		ReasonPublicRecord:        {Code: "AO05", Description: "Protesto ou dívida fiscal registada"},
+		ReasonCollection:          {Code: "AO06", Description: "Crédito em recuperação"},
+		ReasonShortHistory:        {Code: "AO07", Description: "Histórico de crédito recente"},
+		ReasonInsufficientHistory: {Code: "AO08", Description: "Histórico de crédito insuficiente"},
+	},
+	MarketEU: {
+		ReasonRecentDelinquency:   {Code: "EU01", Description: "Payment default in the last 12 months"},
+		ReasonDelinquency:         {Code: "EU02", Description: "Late payments on credit agreements"},
+		ReasonHighUtilization:     {Code: "EU03", Description: "High utilisation of credit limits"},
+		ReasonFraudRecord:         {Code: "EU04", Description: "Fraud record reported"},
+		ReasonPublicRecord:        {Code: "EU05", Description: "Public record or tax debt registered"},
+		ReasonCollection:          {Code: "EU06", Description: "Debt in collection"},
+		ReasonShortHistory:        {Code: "EU07", Description: "Short credit history"},
+		ReasonInsufficientHistory: {Code: "EU08", Description: "Insufficient credit history"},
+	},
+	MarketGlobal: {
+		ReasonRecentDelinquency:   {Code: "GL01", Description: "Atraso ou incumprimento nos últimos 12 meses"},
+		ReasonDelinquency:         {Code: "GL02", Description: "Histórico de pagamentos com atrasos"},
+		ReasonHighUtilization:     {Code: "GL03", Description: "Utilização elevada dos limites de crédito"},
+		ReasonFraudRecord:         {Code: "GL04", Description: "Registro de fraude"},
+		ReasonPublicRecord:        {Code: "GL05", Description: "Registro público negativo"},
+		ReasonCollection:          {Code: "GL06", Description: "Dívida em recuperação de crédito"},
+		ReasonShortHistory:        {Code: "GL07", Description: "Histórico de crédito recente"},
+		ReasonInsufficientHistory: {Code: "GL08", Description: "Histórico de crédito insuficiente"},
+	},
+}
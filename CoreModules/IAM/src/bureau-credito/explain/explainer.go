@@ -0,0 +1,361 @@
+/**
+ * @file explainer.go
+ * @description Explicação do score de crédito: contribuição dos fatores, códigos de motivo do mercado
+ *              e aviso de ação adversa das consultas do mercado americano
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package explain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxAdverseActionReasons é o número de motivos do aviso de ação adversa (FCRA, 15 U.S.C. 1681g(f))
+const MaxAdverseActionReasons = 4
+
+// Valores padrão da explicação
+const (
+	DefaultPointsScale          = 300
+	DefaultUtilizationThreshold = 0.30
+	DefaultRecentWindow         = 365 * 24 * time.Hour
+	DefaultShortHistoryMonths   = 24
+	DefaultMatureHistoryMonths  = 84
+)
+
+// Pesos padrão dos fatores; somam 1
+var defaultWeights = map[Factor]float64{
+	FactorPaymentHistory: 0.35,
+	FactorUtilization:    0.30,
+	FactorDerogatories:   0.20,
+	FactorHistoryLength:  0.15,
+}
+
+// factorOrder é a ordem dos fatores na explicação
+var factorOrder = []Factor{FactorPaymentHistory, FactorUtilization, FactorDerogatories, FactorHistoryLength}
+
+// baselines é a qualidade de cada fator num perfil médio; acima dela o fator soma pontos
+var baselines = map[Factor]float64{
+	FactorPaymentHistory: 0.8, // Pagamentos quase sempre em dia
+	FactorUtilization:    0.7, // Cerca de um terço do limite utilizado
+	FactorDerogatories:   1.0, // Sem registros negativos
+	FactorHistoryLength:  0.5, // Cerca de três anos e meio de histórico
+}
+
+// reasonSeverity desempata os motivos com o mesmo impacto, do mais grave para o menos grave
+var reasonSeverity = map[ReasonKey]int{
+	ReasonFraudRecord:         0,
+	ReasonRecentDelinquency:   1,
+	ReasonPublicRecord:        2,
+	ReasonCollection:          3,
+	ReasonDelinquency:         4,
+	ReasonHighUtilization:     5,
+	ReasonInsufficientHistory: 6,
+	ReasonShortHistory:        7,
+}
+
+// Penalizações da qualidade do fator de registros negativos por registro
+const (
+	penaltyFraud        = 0.6
+	penaltyPublicRecord = 0.35
+	penaltyCollection   = 0.25
+	penaltyRecent       = 0.25 // Penalização do histórico de pagamentos com atraso recente
+)
+
+// Config define a explicação do score
+type Config struct {
+	// Weights é o peso de cada fator; fatores sem peso usam o peso padrão
+	Weights map[Factor]float64 `json:"weights"`
+
+	// PointsScale é a amplitude, em pontos, de um fator com peso 1
+	PointsScale float64 `json:"pointsScale"`
+
+	// UtilizationThreshold é a utilização do limite a partir da qual o motivo é apresentado
+	UtilizationThreshold float64 `json:"utilizationThreshold"`
+
+	// RecentWindow é o período dos atrasos considerados recentes
+	RecentWindow time.Duration `json:"recentWindow"`
+
+	// ShortHistoryMonths é a antiguidade abaixo da qual o histórico é considerado recente
+	ShortHistoryMonths int `json:"shortHistoryMonths"`
+
+	// MatureHistoryMonths é a antiguidade a partir da qual o fator atinge a qualidade máxima
+	MatureHistoryMonths int `json:"matureHistoryMonths"`
+}
+
+// DefaultConfig retorna a configuração padrão da explicação
+func DefaultConfig() Config {
+	weights := make(map[Factor]float64, len(defaultWeights))
+	for factor, weight := range defaultWeights {
+		weights[factor] = weight
+	}
+	return Config{
+		Weights:              weights,
+		PointsScale:          DefaultPointsScale,
+		UtilizationThreshold: DefaultUtilizationThreshold,
+		RecentWindow:         DefaultRecentWindow,
+		ShortHistoryMonths:   DefaultShortHistoryMonths,
+		MatureHistoryMonths:  DefaultMatureHistoryMonths,
+	}
+}
+
+// Explainer calcula a explicação do score de uma consulta
+type Explainer struct {
+	config  Config
+	catalog *Catalog
+	now     func() time.Time
+}
+
+// NewExplainer cria a explicação do score com o catálogo de motivos informado
+func NewExplainer(config Config, catalog *Catalog) *Explainer {
+	defaults := DefaultConfig()
+	weights := make(map[Factor]float64, len(factorOrder))
+	for _, factor := range factorOrder {
+		weights[factor] = defaults.Weights[factor]
+		if weight, ok := config.Weights[factor]; ok && weight >= 0 {
+			weights[factor] = weight
+		}
+	}
+	config.Weights = weights
+	if config.PointsScale <= 0 {
+		config.PointsScale = defaults.PointsScale
+	}
+	if config.UtilizationThreshold <= 0 || config.UtilizationThreshold >= 1 {
+		config.UtilizationThreshold = defaults.UtilizationThreshold
+	}
+	if config.RecentWindow <= 0 {
+		config.RecentWindow = defaults.RecentWindow
+	}
+	if config.ShortHistoryMonths <= 0 {
+		config.ShortHistoryMonths = defaults.ShortHistoryMonths
+	}
+	if config.MatureHistoryMonths <= config.ShortHistoryMonths {
+		config.MatureHistoryMonths = max(defaults.MatureHistoryMonths, config.ShortHistoryMonths+1)
+	}
+	if catalog == nil {
+		catalog = DefaultCatalog()
+	}
+	return &Explainer{config: config, catalog: catalog, now: time.Now}
+}
+
+// SetClock substitui o relógio usado na antiguidade do histórico e nos atrasos recentes
+func (e *Explainer) SetClock(now func() time.Time) {
+	e.now = now
+}
+
+// factorResult é a avaliação de um fator antes da conversão em pontos
+type factorResult struct {
+	value   float64
+	neutral bool // Sem dados para avaliar o fator
+	reasons []ReasonKey
+}
+
+// Explain calcula a contribuição dos fatores e os motivos que reduziram o score
+// Só são motivos os de fatores com contribuição negativa; o aviso de ação adversa é gerado
+// para as consultas do mercado americano com pelo menos um motivo
+func (e *Explainer) Explain(input Input) *Explanation {
+	now := e.now()
+	results := map[Factor]factorResult{
+		FactorPaymentHistory: e.paymentHistory(input, now),
+		FactorUtilization:    e.utilization(input),
+		FactorDerogatories:   e.derogatories(input),
+		FactorHistoryLength:  e.historyLength(input, now),
+	}
+
+	explanation := &Explanation{Market: input.Market, EvaluatedAt: now}
+	for _, factor := range factorOrder {
+		result := results[factor]
+		weight := e.config.Weights[factor]
+		contribution := FactorContribution{
+			Factor:    factor,
+			Weight:    weight,
+			Value:     math.Round(result.value*100) / 100,
+			Direction: DirectionNeutral,
+		}
+		if !result.neutral {
+			contribution.Points = int(math.Round(weight * (result.value - baselines[factor]) * e.config.PointsScale))
+			switch {
+			case contribution.Points > 0:
+				contribution.Direction = DirectionPositive
+			case contribution.Points < 0:
+				contribution.Direction = DirectionNegative
+			}
+		}
+		explanation.Factors = append(explanation.Factors, contribution)
+
+		if contribution.Points >= 0 {
+			continue
+		}
+		for _, key := range result.reasons {
+			code, ok := e.catalog.Lookup(input.Market, key)
+			if !ok {
+				code = ReasonCode{Code: string(key), Description: string(key)}
+			}
+			explanation.Reasons = append(explanation.Reasons, Reason{
+				Key:         key,
+				Code:        code.Code,
+				Factor:      factor,
+				Description: code.Description,
+				Impact:      -contribution.Points,
+			})
+		}
+	}
+
+	sort.SliceStable(explanation.Reasons, func(i, j int) bool {
+		a, b := explanation.Reasons[i], explanation.Reasons[j]
+		if a.Impact != b.Impact {
+			return a.Impact > b.Impact
+		}
+		return reasonSeverity[a.Key] < reasonSeverity[b.Key]
+	})
+
+	if input.Market == MarketUSA && len(explanation.Reasons) > 0 {
+		explanation.AdverseAction = RenderAdverseAction(input.Score, explanation.Reasons)
+	}
+	return explanation
+}
+
+// RenderAdverseAction gera o aviso de ação adversa com os principais motivos, por ordem de impacto
+func RenderAdverseAction(score int, reasons []Reason) *AdverseActionNotice {
+	keyFactors := reasons
+	if len(keyFactors) > MaxAdverseActionReasons {
+		keyFactors = keyFactors[:MaxAdverseActionReasons]
+	}
+	keyFactors = append([]Reason(nil), keyFactors...)
+
+	var text strings.Builder
+	if score > 0 {
+		fmt.Fprintf(&text, "Your credit score: %d\n", score)
+	}
+	text.WriteString("Key factors that adversely affected your credit score:\n")
+	for i, reason := range keyFactors {
+		fmt.Fprintf(&text, "%d. %s - %s\n", i+1, reason.Code, reason.Description)
+	}
+
+	return &AdverseActionNotice{
+		Score:      score,
+		KeyFactors: keyFactors,
+		Text:       strings.TrimSuffix(text.String(), "\n"),
+	}
+}
+
+// paymentHistory avalia os atrasos do histórico e as inadimplências das restrições
+func (e *Explainer) paymentHistory(input Input, now time.Time) factorResult {
+	total, delinquent := 0, 0
+	recent := false
+	count := func(record Record, late bool) {
+		total++
+		if !late {
+			return
+		}
+		delinquent++
+		if now.Sub(record.DataOcorrencia) <= e.config.RecentWindow {
+			recent = true
+		}
+	}
+	for _, record := range input.Records {
+		count(record, record.DiasAtraso > 0)
+	}
+	for _, record := range input.Restrictions {
+		if record.Tipo == TipoInadimplencia {
+			count(record, true)
+		}
+	}
+	if total == 0 {
+		return factorResult{value: baselines[FactorPaymentHistory], neutral: true}
+	}
+
+	result := factorResult{value: 1 - float64(delinquent)/float64(total)}
+	switch {
+	case recent:
+		result.value = clamp(result.value - penaltyRecent)
+		result.reasons = []ReasonKey{ReasonRecentDelinquency}
+	case delinquent > 0:
+		result.reasons = []ReasonKey{ReasonDelinquency}
+	}
+	return result
+}
+
+// utilization avalia o saldo utilizado face aos limites dos registros com limite conhecido
+func (e *Explainer) utilization(input Input) factorResult {
+	var limit, balance float64
+	for _, record := range input.Records {
+		if record.Limite > 0 {
+			limit += record.Limite
+			balance += record.Saldo
+		}
+	}
+	if limit == 0 {
+		return factorResult{value: baselines[FactorUtilization], neutral: true}
+	}
+
+	// Até 10% do limite o fator tem a qualidade máxima; com o limite esgotado, a mínima
+	ratio := balance / limit
+	result := factorResult{value: clamp(1 - math.Max(0, ratio-0.1)/0.9)}
+	if ratio > e.config.UtilizationThreshold {
+		result.reasons = []ReasonKey{ReasonHighUtilization}
+	}
+	return result
+}
+
+// derogatories avalia as restrições de fraude, registros públicos e recuperação de crédito
+func (e *Explainer) derogatories(input Input) factorResult {
+	var fraud, public, collection int
+	for _, record := range input.Restrictions {
+		switch record.Tipo {
+		case TipoFraude:
+			fraud++
+		case TipoProtesto, TipoDividaAtiva:
+			public++
+		case TipoRecuperacaoCredito:
+			collection++
+		}
+	}
+
+	result := factorResult{value: clamp(1 -
+		penaltyFraud*float64(fraud) -
+		penaltyPublicRecord*float64(public) -
+		penaltyCollection*float64(collection))}
+	if fraud > 0 {
+		result.reasons = append(result.reasons, ReasonFraudRecord)
+	}
+	if public > 0 {
+		result.reasons = append(result.reasons, ReasonPublicRecord)
+	}
+	if collection > 0 {
+		result.reasons = append(result.reasons, ReasonCollection)
+	}
+	return result
+}
+
+// historyLength avalia a antiguidade do registro mais antigo
+func (e *Explainer) historyLength(input Input, now time.Time) factorResult {
+	var oldest time.Time
+	for _, records := range [][]Record{input.Records, input.Restrictions} {
+		for _, record := range records {
+			if !record.DataOcorrencia.IsZero() && (oldest.IsZero() || record.DataOcorrencia.Before(oldest)) {
+				oldest = record.DataOcorrencia
+			}
+		}
+	}
+	if oldest.IsZero() {
+		return factorResult{value: 0, reasons: []ReasonKey{ReasonInsufficientHistory}}
+	}
+
+	months := now.Sub(oldest).Hours() / (24 * 30)
+	result := factorResult{value: clamp(months / float64(e.config.MatureHistoryMonths))}
+	if months < float64(e.config.ShortHistoryMonths) {
+		result.reasons = []ReasonKey{ReasonShortHistory}
+	}
+	return result
+}
+
+// clamp limita a qualidade de um fator ao intervalo [0, 1]
+func clamp(value float64) float64 {
+	return math.Min(1, math.Max(0, value))
+}
@@ -0,0 +1,121 @@
+/**
+ * @file models.go
+ * @description Tipos da explicação do score de crédito: fatores, códigos de motivo e aviso de ação adversa
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package explain
+
+import "time"
+
+// Factor identifica um fator do score de crédito
+type Factor string
+
+const (
+	FactorPaymentHistory Factor = "historico_pagamentos" // Atrasos e inadimplência no histórico
+	FactorUtilization    Factor = "utilizacao_credito"   // Saldo utilizado face aos limites concedidos
+	FactorDerogatories   Factor = "registros_negativos"  // Protestos, dívida ativa, fraude e recuperação de crédito
+	FactorHistoryLength  Factor = "tempo_historico"      // Antiguidade do histórico de crédito
+)
+
+// Direções da contribuição de um fator para o score
+const (
+	DirectionPositive = "positivo"
+	DirectionNegative = "negativo"
+	DirectionNeutral  = "neutro" // Sem efeito no score ou sem dados para avaliar o fator
+)
+
+// ReasonKey identifica um motivo padronizado, independente do mercado
+// O código e a descrição apresentados ao titular vêm do catálogo do mercado
+type ReasonKey string
+
+const (
+	ReasonRecentDelinquency   ReasonKey = "recent_delinquency"   // Atraso ou inadimplência nos últimos 12 meses
+	ReasonDelinquency         ReasonKey = "delinquency"          // Atrasos no histórico de pagamentos
+	ReasonHighUtilization     ReasonKey = "high_utilization"     // Utilização do limite acima do recomendado
+	ReasonFraudRecord         ReasonKey = "fraud_record"         // Registro de fraude
+	ReasonPublicRecord        ReasonKey = "public_record"        // Protesto ou inscrição em dívida ativa
+	ReasonCollection          ReasonKey = "collection"           // Dívida em recuperação de crédito
+	ReasonShortHistory        ReasonKey = "short_history"        // Histórico de crédito recente
+	ReasonInsufficientHistory ReasonKey = "insufficient_history" // Sem histórico de crédito
+)
+
+// reasonFactors associa cada motivo ao fator que o origina
+var reasonFactors = map[ReasonKey]Factor{
+	ReasonRecentDelinquency:   FactorPaymentHistory,
+	ReasonDelinquency:         FactorPaymentHistory,
+	ReasonHighUtilization:     FactorUtilization,
+	ReasonFraudRecord:         FactorDerogatories,
+	ReasonPublicRecord:        FactorDerogatories,
+	ReasonCollection:          FactorDerogatories,
+	ReasonShortHistory:        FactorHistoryLength,
+	ReasonInsufficientHistory: FactorHistoryLength,
+}
+
+// Tipos de registro avaliados nos fatores; os demais tipos contam apenas para a antiguidade do histórico
+const (
+	TipoInadimplencia      = "inadimplencia"
+	TipoFraude             = "fraude"
+	TipoProtesto           = "protesto"
+	TipoDividaAtiva        = "divida_ativa"
+	TipoRecuperacaoCredito = "recuperacao_credito"
+)
+
+// Record é o registro de crédito avaliado nos fatores
+type Record struct {
+	Tipo           string    `json:"tipo"`
+	Limite         float64   `json:"limite,omitempty"`     // Limite concedido; zero quando desconhecido
+	Saldo          float64   `json:"saldo,omitempty"`      // Saldo utilizado do limite
+	DiasAtraso     int       `json:"diasAtraso,omitempty"` // Dias de atraso no pagamento
+	DataOcorrencia time.Time `json:"dataOcorrencia"`
+}
+
+// Input é o pedido de explicação do score de uma consulta
+type Input struct {
+	Market       string   `json:"market"`
+	Score        int      `json:"score"`
+	Records      []Record `json:"records"`      // Histórico de crédito
+	Restrictions []Record `json:"restrictions"` // Restrições (inadimplência, protestos, dívida ativa, fraude)
+}
+
+// FactorContribution é a contribuição estimada de um fator para o score
+// Points é a diferença estimada, em pontos, face a um perfil médio do mercado
+type FactorContribution struct {
+	Factor    Factor  `json:"fator"`
+	Weight    float64 `json:"peso"`
+	Value     float64 `json:"valor"` // Qualidade do fator, de 0 (pior) a 1 (melhor)
+	Points    int     `json:"pontos"`
+	Direction string  `json:"direcao"`
+}
+
+// ReasonCode é a entrada do catálogo de um mercado para um motivo
+type ReasonCode struct {
+	Code        string `json:"codigo"`
+	Description string `json:"descricao"`
+}
+
+// Reason é um motivo padronizado que reduziu o score da consulta
+type Reason struct {
+	Key         ReasonKey `json:"chave"`
+	Code        string    `json:"codigo"`
+	Factor      Factor    `json:"fator"`
+	Description string    `json:"descricao"`
+	Impact      int       `json:"impacto"` // Pontos perdidos pelo fator do motivo
+}
+
+// AdverseActionNotice contém os principais motivos de ação adversa exigidos pelo FCRA
+type AdverseActionNotice struct {
+	Score      int      `json:"score"`
+	KeyFactors []Reason `json:"fatoresChave"` // No máximo MaxAdverseActionReasons, por ordem de impacto
+	Text       string   `json:"texto"`
+}
+
+// Explanation é a explicação do score acrescentada ao resultado da consulta
+type Explanation struct {
+	Market        string               `json:"market"`
+	Factors       []FactorContribution `json:"fatores"`
+	Reasons       []Reason             `json:"motivos,omitempty"` // Por ordem de impacto
+	AdverseAction *AdverseActionNotice `json:"acaoAdversa,omitempty"`
+	EvaluatedAt   time.Time            `json:"avaliadoEm"`
+}
@@ -0,0 +1,146 @@
+/**
+ * @file explainer_test.go
+ * @description Testes da explicação do score de crédito e do aviso de ação adversa
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/explain"
+)
+
+var referenceTime = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newExplainer() *explain.Explainer {
+	explainer := explain.NewExplainer(explain.DefaultConfig(), explain.DefaultCatalog())
+	explainer.SetClock(func() time.Time { return referenceTime })
+	return explainer
+}
+
+func monthsAgo(months int) time.Time {
+	return referenceTime.AddDate(0, -months, 0)
+}
+
+// adverseInput é um histórico recente, com atraso recente, limites quase esgotados, protesto e fraude
+func adverseInput(market string) explain.Input {
+	return explain.Input{
+		Market: market,
+		Score:  580,
+		Records: []explain.Record{
+			{Tipo: "historico_credito", Limite: 1000, Saldo: 900, DiasAtraso: 45, DataOcorrencia: monthsAgo(2)},
+			{Tipo: "historico_credito", Limite: 1000, Saldo: 700, DataOcorrencia: monthsAgo(10)},
+			{Tipo: "score_credito", DataOcorrencia: monthsAgo(18)},
+		},
+		Restrictions: []explain.Record{
+			{Tipo: explain.TipoProtesto, DataOcorrencia: monthsAgo(5)},
+			{Tipo: explain.TipoFraude, DataOcorrencia: monthsAgo(8)},
+		},
+	}
+}
+
+func reasonCodes(reasons []explain.Reason) []string {
+	codes := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		codes = append(codes, reason.Code)
+	}
+	return codes
+}
+
+// TestExplainUSAdverseAction verifica as contribuições dos fatores e os quatro principais motivos do aviso FCRA
+func TestExplainUSAdverseAction(t *testing.T) {
+	explanation := newExplainer().Explain(adverseInput(explain.MarketUSA))
+
+	require.Len(t, explanation.Factors, 4)
+	points := map[explain.Factor]int{}
+	for _, factor := range explanation.Factors {
+		points[factor.Factor] = factor.Points
+		assert.Equal(t, explain.DirectionNegative, factor.Direction, factor.Factor)
+	}
+	assert.Equal(t, -40, points[explain.FactorPaymentHistory])
+	assert.Equal(t, -43, points[explain.FactorUtilization])
+	assert.Equal(t, -57, points[explain.FactorDerogatories])
+	assert.Equal(t, -13, points[explain.FactorHistoryLength])
+
+	// Ordenados por impacto; no mesmo fator, o motivo mais grave primeiro
+	assert.Equal(t, []string{"US04", "US05", "US03", "US01", "US07"}, reasonCodes(explanation.Reasons))
+	assert.Equal(t, 57, explanation.Reasons[0].Impact)
+
+	notice := explanation.AdverseAction
+	require.NotNil(t, notice)
+	assert.Equal(t, 580, notice.Score)
+	assert.Equal(t, []string{"US04", "US05", "US03", "US01"}, reasonCodes(notice.KeyFactors))
+	assert.Equal(t, "Your credit score: 580\n"+
+		"Key factors that adversely affected your credit score:\n"+
+		"1. US04 - Fraud record reported on file\n"+
+		"2. US05 - Derogatory public record or tax lien filed\n"+
+		"3. US03 - Proportion of balances to credit limits is too high\n"+
+		"4. US01 - Serious delinquency in the last 12 months", notice.Text)
+}
+
+// TestExplainMarketCatalog verifica os códigos do catálogo de cada mercado e o catálogo global
+func TestExplainMarketCatalog(t *testing.T) {
+	explainer := newExplainer()
+
+	brazil := explainer.Explain(adverseInput(explain.MarketBrazil))
+	assert.Equal(t, []string{"BR04", "BR05", "BR03", "BR01", "BR07"}, reasonCodes(brazil.Reasons))
+	assert.Equal(t, "Protesto em cartório ou inscrição em dívida ativa", brazil.Reasons[1].Description)
+	assert.Nil(t, brazil.AdverseAction, "o aviso de ação adversa é exclusivo do mercado americano")
+
+	// Mercado sem catálogo próprio e sem histórico
+	sadc := explainer.Explain(explain.Input{Market: "SADC", Score: 600})
+	require.Len(t, sadc.Reasons, 1)
+	assert.Equal(t, explain.ReasonInsufficientHistory, sadc.Reasons[0].Key)
+	assert.Equal(t, "GL08", sadc.Reasons[0].Code)
+	for _, factor := range sadc.Factors {
+		if factor.Factor == explain.FactorPaymentHistory || factor.Factor == explain.FactorUtilization {
+			assert.Equal(t, explain.DirectionNeutral, factor.Direction)
+			assert.Zero(t, factor.Points)
+		}
+	}
+}
+
+// TestExplainEstablishedProfile verifica que um histórico regular não gera motivos nem aviso de ação adversa
+func TestExplainEstablishedProfile(t *testing.T) {
+	input := explain.Input{Market: explain.MarketUSA, Score: 810}
+	for i := 0; i < 5; i++ {
+		input.Records = append(input.Records, explain.Record{
+			Tipo:           "historico_credito",
+			Limite:         10000,
+			Saldo:          500,
+			DataOcorrencia: monthsAgo(12 + i*20),
+		})
+	}
+
+	explanation := newExplainer().Explain(input)
+	assert.Empty(t, explanation.Reasons)
+	assert.Nil(t, explanation.AdverseAction)
+
+	directions := map[explain.Factor]string{}
+	for _, factor := range explanation.Factors {
+		directions[factor.Factor] = factor.Direction
+	}
+	assert.Equal(t, explain.DirectionPositive, directions[explain.FactorPaymentHistory])
+	assert.Equal(t, explain.DirectionPositive, directions[explain.FactorUtilization])
+	assert.Equal(t, explain.DirectionNeutral, directions[explain.FactorDerogatories])
+	assert.Equal(t, explain.DirectionPositive, directions[explain.FactorHistoryLength])
+}
+
+// TestCatalogRegisterRequiresAllReasons verifica que um catálogo incompleto é rejeitado
+func TestCatalogRegisterRequiresAllReasons(t *testing.T) {
+	catalog := explain.NewCatalog()
+	err := catalog.Register("Mozambique", map[explain.ReasonKey]explain.ReasonCode{
+		explain.ReasonDelinquency: {Code: "MZ02", Description: "Histórico de pagamentos com atrasos"},
+	})
+	assert.Error(t, err)
+
+	_, ok := catalog.Lookup("Mozambique", explain.ReasonDelinquency)
+	assert.False(t, ok)
+}
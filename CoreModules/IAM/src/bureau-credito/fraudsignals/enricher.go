@@ -0,0 +1,406 @@
+/**
+ * @file enricher.go
+ * @description Enriquecimento das consultas com sinais de fraude dos provedores de inteligência
+ *              e heurísticas de identidade sintética
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package fraudsignals
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SourceHeuristic identifica os sinais das heurísticas de identidade sintética
+const SourceHeuristic = "heuristica"
+
+// Resultado da chamada a um provedor registado nas métricas
+const (
+	OutcomeOK      = "ok"
+	OutcomeError   = "error"
+	OutcomeSkipped = "skipped" // Sujeito sem o dado avaliado pelo provedor
+)
+
+// Valores padrão do enriquecimento
+const (
+	DefaultProviderTimeout    = 300 * time.Millisecond
+	DefaultThinFileMaxRecords = 3
+	DefaultDormantAfter       = 24 * 30 * 24 * time.Hour // 24 meses
+	DefaultHighValueThreshold = 50000
+	DefaultMediumScore        = 30
+	DefaultHighScore          = 60
+	DefaultCriticalScore      = 85
+)
+
+// Pontuação dos sinais das heurísticas
+const (
+	scoreThinFile            = 15
+	scoreInclusionBeforeFact = 45
+	scoreFutureOccurrence    = 50
+	scoreDormantHistory      = 30
+	scoreHighValueThinFile   = 40
+	scoreForeignDocument     = 35
+)
+
+// Config define o enriquecimento com sinais de fraude
+type Config struct {
+	// ProviderTimeout limita a chamada a cada provedor; os provedores são consultados em paralelo
+	ProviderTimeout time.Duration `json:"providerTimeout"`
+
+	// ThinFileMaxRecords é o número máximo de registros de um histórico considerado escasso
+	ThinFileMaxRecords int `json:"thinFileMaxRecords"`
+
+	// DormantAfter é o tempo sem registros a partir do qual um histórico escasso é considerado dormente
+	DormantAfter time.Duration `json:"dormantAfter"`
+
+	// HighValueThreshold é o valor de um registro considerado elevado para um histórico escasso
+	HighValueThreshold float64 `json:"highValueThreshold"`
+
+	// Limites do score (0-100) de cada nível de risco
+	MediumScore   int `json:"mediumScore"`
+	HighScore     int `json:"highScore"`
+	CriticalScore int `json:"criticalScore"`
+}
+
+// DefaultConfig retorna a configuração padrão do enriquecimento
+func DefaultConfig() Config {
+	return Config{
+		ProviderTimeout:    DefaultProviderTimeout,
+		ThinFileMaxRecords: DefaultThinFileMaxRecords,
+		DormantAfter:       DefaultDormantAfter,
+		HighValueThreshold: DefaultHighValueThreshold,
+		MediumScore:        DefaultMediumScore,
+		HighScore:          DefaultHighScore,
+		CriticalScore:      DefaultCriticalScore,
+	}
+}
+
+// Enricher avalia o risco de fraude de uma consulta com os provedores de inteligência
+// e as heurísticas de identidade sintética
+type Enricher struct {
+	config    Config
+	providers []Provider
+	metrics   *Metrics
+	now       func() time.Time
+}
+
+// NewEnricher cria o enriquecimento com os provedores configurados; sem provedores aplica apenas as heurísticas
+func NewEnricher(config Config, providers ...Provider) *Enricher {
+	defaults := DefaultConfig()
+	if config.ProviderTimeout <= 0 {
+		config.ProviderTimeout = defaults.ProviderTimeout
+	}
+	if config.ThinFileMaxRecords <= 0 {
+		config.ThinFileMaxRecords = defaults.ThinFileMaxRecords
+	}
+	if config.DormantAfter <= 0 {
+		config.DormantAfter = defaults.DormantAfter
+	}
+	if config.HighValueThreshold <= 0 {
+		config.HighValueThreshold = defaults.HighValueThreshold
+	}
+	if config.MediumScore <= 0 || config.HighScore <= config.MediumScore || config.CriticalScore <= config.HighScore {
+		config.MediumScore = defaults.MediumScore
+		config.HighScore = defaults.HighScore
+		config.CriticalScore = defaults.CriticalScore
+	}
+	return &Enricher{
+		config:    config,
+		providers: providers,
+		now:       time.Now,
+	}
+}
+
+// SetClock substitui o relógio usado nas heurísticas
+func (e *Enricher) SetClock(now func() time.Time) {
+	e.now = now
+}
+
+// SetMetrics define as métricas Prometheus do enriquecimento
+func (e *Enricher) SetMetrics(metrics *Metrics) {
+	e.metrics = metrics
+}
+
+// Enrich avalia o risco de fraude da consulta
+// A falha de um provedor não interrompe a avaliação: fica registada em FailedProviders
+func (e *Enricher) Enrich(ctx context.Context, req Request) *FraudRisk {
+	started := e.now()
+	risk := &FraudRisk{EvaluatedAt: started}
+
+	signals, failed := e.evaluateProviders(ctx, req.Subject)
+	heuristics, synthetic := e.syntheticIdentitySignals(req.Subject, req.Records, started)
+	signals = append(signals, heuristics...)
+
+	risk.Signals = signals
+	risk.FailedProviders = failed
+	risk.SyntheticIdentity = synthetic
+	risk.Score = combinedScore(signals)
+	risk.Level = e.level(risk.Score)
+	for _, signal := range signals {
+		if signal.Description != "" {
+			risk.Reasons = append(risk.Reasons, signal.Description)
+		}
+	}
+	if synthetic {
+		risk.Reasons = append(risk.Reasons, "Histórico escasso e inconsistente: indícios de identidade sintética")
+	}
+
+	e.metrics.observeRisk(risk)
+	return risk
+}
+
+// providerResult é a resposta de um provedor consultado em paralelo
+type providerResult struct {
+	signals []Signal
+	err     error
+}
+
+// evaluateProviders consulta em paralelo os provedores com dados do sujeito para avaliar
+// Os sinais são devolvidos pela ordem de registo dos provedores
+func (e *Enricher) evaluateProviders(ctx context.Context, subject Subject) ([]Signal, []string) {
+	results := make([]providerResult, len(e.providers))
+	var wg sync.WaitGroup
+	for i, provider := range e.providers {
+		if !subjectHasData(subject, provider.Type()) {
+			e.metrics.observeProvider(provider, OutcomeSkipped, 0)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+
+			callCtx, cancel := context.WithTimeout(ctx, e.config.ProviderTimeout)
+			defer cancel()
+
+			started := time.Now()
+			signals, err := provider.Evaluate(callCtx, subject)
+			outcome := OutcomeOK
+			if err != nil {
+				outcome = OutcomeError
+			}
+			e.metrics.observeProvider(provider, outcome, time.Since(started))
+			results[i] = providerResult{signals: signals, err: err}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var signals []Signal
+	var failed []string
+	for i, result := range results {
+		if result.err != nil {
+			failed = append(failed, e.providers[i].Name())
+			continue
+		}
+		for _, signal := range result.signals {
+			if signal.Source == "" {
+				signal.Source = e.providers[i].Name()
+			}
+			if signal.Type == "" {
+				signal.Type = e.providers[i].Type()
+			}
+			signal.Score = clampScore(signal.Score)
+			signals = append(signals, signal)
+		}
+	}
+	return signals, failed
+}
+
+// subjectHasData indica se o sujeito tem o dado avaliado pela categoria do provedor
+func subjectHasData(subject Subject, signalType SignalType) bool {
+	switch signalType {
+	case SignalDevice:
+		return strings.TrimSpace(subject.DispositivoID) != "" || strings.TrimSpace(subject.IP) != ""
+	case SignalPhone:
+		return strings.TrimSpace(subject.Telefone) != ""
+	case SignalEmail:
+		return strings.TrimSpace(subject.Email) != ""
+	default:
+		return true
+	}
+}
+
+// syntheticIdentitySignals aplica as heurísticas de identidade sintética ao histórico do sujeito
+// A identidade é considerada sintética quando o histórico é escasso e apresenta alguma inconsistência;
+// sem histórico consultado (records nil) as heurísticas não se aplicam
+func (e *Enricher) syntheticIdentitySignals(subject Subject, records []CreditRecord, now time.Time) ([]Signal, bool) {
+	if records == nil || len(records) > e.config.ThinFileMaxRecords {
+		return nil, false
+	}
+
+	signals := []Signal{{
+		Source:      SourceHeuristic,
+		Type:        SignalSynthetic,
+		Code:        CodeThinFile,
+		Score:       scoreThinFile,
+		Description: fmt.Sprintf("Histórico com %d registro(s)", len(records)),
+	}}
+	add := func(code string, score int, description string) {
+		for _, signal := range signals {
+			if signal.Code == code {
+				return
+			}
+		}
+		signals = append(signals, Signal{
+			Source:      SourceHeuristic,
+			Type:        SignalSynthetic,
+			Code:        code,
+			Score:       score,
+			Description: description,
+		})
+	}
+
+	documento := normalizeDocument(subject.Documento)
+	var latest time.Time
+	for _, record := range records {
+		if !record.DataInclusao.IsZero() && record.DataInclusao.Before(record.DataOcorrencia) {
+			add(CodeInclusionBeforeFact, scoreInclusionBeforeFact, "Registro incluído antes da data de ocorrência")
+		}
+		if record.DataOcorrencia.After(now) {
+			add(CodeFutureOccurrence, scoreFutureOccurrence, "Registro com ocorrência posterior à consulta")
+		}
+		if record.Valor >= e.config.HighValueThreshold {
+			add(CodeHighValueThinFile, scoreHighValueThinFile, "Valores elevados num histórico com poucos registros")
+		}
+		if documento != "" && record.Documento != "" && normalizeDocument(record.Documento) != documento {
+			add(CodeForeignDocument, scoreForeignDocument, "Histórico com registros de outro documento")
+		}
+		if record.DataOcorrencia.After(latest) {
+			latest = record.DataOcorrencia
+		}
+	}
+	if !latest.IsZero() && now.Sub(latest) >= e.config.DormantAfter {
+		add(CodeDormantHistory, scoreDormantHistory, "Histórico antigo sem atividade recente")
+	}
+
+	return signals, len(signals) > 1
+}
+
+// normalizeDocument remove a pontuação do documento para comparação
+func normalizeDocument(documento string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, strings.ToUpper(documento))
+}
+
+// combinedScore combina os sinais como probabilidades independentes: 100 × (1 − Π(1 − score/100))
+func combinedScore(signals []Signal) int {
+	remaining := 1.0
+	for _, signal := range signals {
+		remaining *= 1 - float64(clampScore(signal.Score))/100
+	}
+	return clampScore(int((1-remaining)*100 + 0.5))
+}
+
+// level classifica o score nos níveis de risco configurados
+func (e *Enricher) level(score int) string {
+	switch {
+	case score >= e.config.CriticalScore:
+		return LevelCritical
+	case score >= e.config.HighScore:
+		return LevelHigh
+	case score >= e.config.MediumScore:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
+
+// clampScore limita o score à escala 0-100
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+// Metrics contém as métricas Prometheus do enriquecimento com sinais de fraude
+type Metrics struct {
+	avaliacoesCounter   *prometheus.CounterVec
+	scoreHistogram      prometheus.Histogram
+	sinaisCounter       *prometheus.CounterVec
+	provedoresCounter   *prometheus.CounterVec
+	provedoresHistogram *prometheus.HistogramVec
+}
+
+// NewMetrics cria e regista as métricas do enriquecimento
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		avaliacoesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_fraude_avaliacoes_total",
+				Help: "Número total de consultas enriquecidas pelo nível de risco de fraude e indício de identidade sintética",
+			},
+			[]string{"nivel", "identidade_sintetica"},
+		),
+		scoreHistogram: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "bureau_credito_fraude_score",
+				Help:    "Distribuição do score de risco de fraude (0-100)",
+				Buckets: prometheus.LinearBuckets(10, 10, 10),
+			},
+		),
+		sinaisCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_fraude_sinais_total",
+				Help: "Número total de sinais de fraude por categoria e código",
+			},
+			[]string{"tipo", "codigo"},
+		),
+		provedoresCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_fraude_provedor_chamadas_total",
+				Help: "Número total de chamadas aos provedores de inteligência pelo resultado (ok, error, skipped)",
+			},
+			[]string{"provedor", "tipo", "outcome"},
+		),
+		provedoresHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "bureau_credito_fraude_provedor_duracao_segundos",
+				Help:    "Duração das chamadas aos provedores de inteligência",
+				Buckets: prometheus.ExponentialBuckets(0.005, 2, 8),
+			},
+			[]string{"provedor", "tipo"},
+		),
+	}
+
+	registry.MustRegister(m.avaliacoesCounter, m.scoreHistogram, m.sinaisCounter, m.provedoresCounter, m.provedoresHistogram)
+	return m
+}
+
+// observeRisk regista o resultado de uma avaliação
+func (m *Metrics) observeRisk(risk *FraudRisk) {
+	if m == nil {
+		return
+	}
+	m.avaliacoesCounter.WithLabelValues(risk.Level, fmt.Sprintf("%t", risk.SyntheticIdentity)).Inc()
+	m.scoreHistogram.Observe(float64(risk.Score))
+
+	for _, signal := range risk.Signals {
+		m.sinaisCounter.WithLabelValues(string(signal.Type), signal.Code).Inc()
+	}
+}
+
+// observeProvider regista a chamada a um provedor
+func (m *Metrics) observeProvider(provider Provider, outcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.provedoresCounter.WithLabelValues(provider.Name(), string(provider.Type()), outcome).Inc()
+	if outcome != OutcomeSkipped {
+		m.provedoresHistogram.WithLabelValues(provider.Name(), string(provider.Type())).Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,115 @@
+/**
+ * @file http_provider.go
+ * @description Provedor de inteligência de dispositivo, telefone ou e-mail acedido por HTTP
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package fraudsignals
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIKeyHeader é o cabeçalho com a chave de acesso ao provedor
+const APIKeyHeader = "X-Api-Key"
+
+// HTTPProviderConfig define um provedor acedido por HTTP
+type HTTPProviderConfig struct {
+	Name     string     `json:"name"`
+	Type     SignalType `json:"type"`
+	Endpoint string     `json:"endpoint"`
+	APIKey   string     `json:"-"`
+}
+
+// httpProviderResponse é a resposta esperada do provedor
+type httpProviderResponse struct {
+	Signals []Signal `json:"signals"`
+}
+
+// HTTPProvider envia ao endpoint do provedor, por HTTP POST, apenas os dados da sua categoria
+// e espera os sinais em {"signals": [...]}
+type HTTPProvider struct {
+	client *http.Client
+	config HTTPProviderConfig
+}
+
+// NewHTTPProvider cria o provedor; client pode ser nil
+// O prazo de cada chamada é o do Enricher
+func NewHTTPProvider(client *http.Client, config HTTPProviderConfig) *HTTPProvider {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if config.Name == "" {
+		config.Name = string(config.Type)
+	}
+	return &HTTPProvider{client: client, config: config}
+}
+
+// Name identifica o provedor
+func (p *HTTPProvider) Name() string {
+	return p.config.Name
+}
+
+// Type é a categoria de inteligência do provedor
+func (p *HTTPProvider) Type() SignalType {
+	return p.config.Type
+}
+
+// Evaluate envia os dados do sujeito ao provedor e retorna os sinais devolvidos
+func (p *HTTPProvider) Evaluate(ctx context.Context, subject Subject) ([]Signal, error) {
+	body, err := json.Marshal(minimizeSubject(subject, p.config.Type))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar pedido ao provedor %s: %w", p.config.Name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set(APIKeyHeader, p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("provedor %s indisponível: %w", p.config.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("provedor %s respondeu %d", p.config.Name, resp.StatusCode)
+	}
+
+	var decoded httpProviderResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("resposta inválida do provedor %s: %w", p.config.Name, err)
+	}
+	return decoded.Signals, nil
+}
+
+// minimizeSubject mantém apenas os dados do sujeito avaliados pela categoria do provedor
+func minimizeSubject(subject Subject, signalType SignalType) Subject {
+	minimized := Subject{TenantID: subject.TenantID, Market: subject.Market}
+	switch signalType {
+	case SignalDevice:
+		minimized.DispositivoID = subject.DispositivoID
+		minimized.IP = subject.IP
+	case SignalPhone:
+		minimized.Telefone = subject.Telefone
+		minimized.Nome = subject.Nome
+	case SignalEmail:
+		minimized.Email = subject.Email
+		minimized.Nome = subject.Nome
+	default:
+		return subject
+	}
+	return minimized
+}
@@ -0,0 +1,103 @@
+/**
+ * @file models.go
+ * @description Tipos do enriquecimento das consultas ao Bureau de Crédito com sinais de fraude
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package fraudsignals
+
+import (
+	"context"
+	"time"
+)
+
+// SignalType identifica a categoria de inteligência que originou um sinal
+type SignalType string
+
+const (
+	SignalDevice    SignalType = "device"    // Inteligência de dispositivo
+	SignalPhone     SignalType = "phone"     // Inteligência de telefone
+	SignalEmail     SignalType = "email"     // Inteligência de e-mail
+	SignalSynthetic SignalType = "synthetic" // Heurísticas de identidade sintética
+)
+
+// Níveis de risco de fraude
+const (
+	LevelLow      = "baixo"
+	LevelMedium   = "medio"
+	LevelHigh     = "alto"
+	LevelCritical = "critico"
+)
+
+// Códigos dos sinais das heurísticas de identidade sintética
+const (
+	CodeThinFile            = "thin_file"             // Histórico com poucos registros
+	CodeInclusionBeforeFact = "inclusion_before_fact" // Registro incluído antes da data de ocorrência
+	CodeFutureOccurrence    = "future_occurrence"     // Ocorrência posterior à consulta
+	CodeDormantHistory      = "dormant_history"       // Histórico antigo e esparso, sem atividade recente
+	CodeHighValueThinFile   = "high_value_thin_file"  // Valores elevados num histórico com poucos registros
+	CodeForeignDocument     = "foreign_document"      // Registros de outro documento no histórico
+)
+
+// Subject são os dados de identificação e contacto avaliados pelos provedores
+type Subject struct {
+	TenantID      string `json:"tenantId"`
+	Market        string `json:"market,omitempty"`
+	Documento     string `json:"documento,omitempty"`
+	TipoEntidade  string `json:"tipoEntidade,omitempty"` // PF ou PJ
+	Nome          string `json:"nome,omitempty"`
+	Telefone      string `json:"telefone,omitempty"`
+	Email         string `json:"email,omitempty"`
+	DispositivoID string `json:"dispositivoId,omitempty"`
+	IP            string `json:"ip,omitempty"`
+}
+
+// CreditRecord é o registro de crédito avaliado pelas heurísticas de identidade sintética
+type CreditRecord struct {
+	Documento      string    `json:"documento,omitempty"`
+	Tipo           string    `json:"tipo"`
+	Origem         string    `json:"origem,omitempty"`
+	Valor          float64   `json:"valor"`
+	DataOcorrencia time.Time `json:"dataOcorrencia"`
+	DataInclusao   time.Time `json:"dataInclusao"`
+}
+
+// Request é o pedido de enriquecimento de uma consulta
+type Request struct {
+	ConsultaID string         `json:"consultaId"`
+	Subject    Subject        `json:"subject"`
+	Records    []CreditRecord `json:"records"` // nil quando o histórico não foi consultado: as heurísticas não se aplicam
+}
+
+// Signal é um indício de fraude devolvido por um provedor ou pelas heurísticas
+type Signal struct {
+	Source      string     `json:"source"` // Provedor ou "heuristica"
+	Type        SignalType `json:"type"`
+	Code        string     `json:"code"`
+	Score       int        `json:"score"` // 0-100
+	Description string     `json:"description,omitempty"`
+}
+
+// FraudRisk é a secção de risco de fraude acrescentada ao resultado da consulta
+type FraudRisk struct {
+	Score             int       `json:"score"` // 0-100
+	Level             string    `json:"nivel"`
+	SyntheticIdentity bool      `json:"identidadeSintetica"`
+	Signals           []Signal  `json:"sinais,omitempty"`
+	Reasons           []string  `json:"motivos,omitempty"`
+	FailedProviders   []string  `json:"provedoresIndisponiveis,omitempty"` // Provedores sem resposta; o score pode estar subavaliado
+	EvaluatedAt       time.Time `json:"avaliadoEm"`
+}
+
+// Provider consulta um serviço de inteligência de dispositivo, telefone ou e-mail
+type Provider interface {
+	// Name identifica o provedor nos sinais e nas métricas
+	Name() string
+
+	// Type é a categoria de inteligência do provedor
+	Type() SignalType
+
+	// Evaluate retorna os sinais do sujeito; sem dados para avaliar, retorna uma lista vazia
+	Evaluate(ctx context.Context, subject Subject) ([]Signal, error)
+}
@@ -0,0 +1,362 @@
+/**
+ * @file enricher_test.go
+ * @description Testes do enriquecimento das consultas com sinais de fraude
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/fraudsignals"
+)
+
+var referenceTime = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// stubProvider devolve sinais fixos e conta as chamadas
+type stubProvider struct {
+	name       string
+	signalType fraudsignals.SignalType
+	signals    []fraudsignals.Signal
+	err        error
+	delay      time.Duration
+	calls      int
+}
+
+func (p *stubProvider) Name() string                  { return p.name }
+func (p *stubProvider) Type() fraudsignals.SignalType { return p.signalType }
+func (p *stubProvider) Evaluate(ctx context.Context, subject fraudsignals.Subject) ([]fraudsignals.Signal, error) {
+	p.calls++
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.signals, p.err
+}
+
+func newEnricher(providers ...fraudsignals.Provider) *fraudsignals.Enricher {
+	enricher := fraudsignals.NewEnricher(fraudsignals.DefaultConfig(), providers...)
+	enricher.SetClock(func() time.Time { return referenceTime })
+	return enricher
+}
+
+// establishedHistory é um histórico regular, com mais registros do que o limite de histórico escasso
+func establishedHistory() []fraudsignals.CreditRecord {
+	records := make([]fraudsignals.CreditRecord, 0, 6)
+	for i := 1; i <= 6; i++ {
+		ocorrencia := referenceTime.AddDate(0, -i, 0)
+		records = append(records, fraudsignals.CreditRecord{
+			Documento:      "123.456.789-00",
+			Tipo:           "historico_credito",
+			Valor:          1500,
+			DataOcorrencia: ocorrencia,
+			DataInclusao:   ocorrencia.AddDate(0, 0, 2),
+		})
+	}
+	return records
+}
+
+func signalCodes(risk *fraudsignals.FraudRisk) []string {
+	codes := make([]string, 0, len(risk.Signals))
+	for _, signal := range risk.Signals {
+		codes = append(codes, signal.Code)
+	}
+	return codes
+}
+
+func TestEstablishedHistoryWithoutSignalsIsLowRisk(t *testing.T) {
+	risk := newEnricher().Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "123.456.789-00"},
+		Records: establishedHistory(),
+	})
+
+	assert.Equal(t, 0, risk.Score)
+	assert.Equal(t, fraudsignals.LevelLow, risk.Level)
+	assert.False(t, risk.SyntheticIdentity)
+	assert.Empty(t, risk.Signals)
+	assert.Equal(t, referenceTime, risk.EvaluatedAt)
+}
+
+func TestThinFileAloneIsNotSyntheticIdentity(t *testing.T) {
+	records := establishedHistory()[:2]
+	risk := newEnricher().Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: records,
+	})
+
+	assert.Equal(t, []string{fraudsignals.CodeThinFile}, signalCodes(risk))
+	assert.False(t, risk.SyntheticIdentity, "um histórico escasso e consistente é um cliente novo")
+	assert.Equal(t, fraudsignals.LevelLow, risk.Level)
+}
+
+func TestThinFileWithInconsistentHistoryIsSyntheticIdentity(t *testing.T) {
+	records := []fraudsignals.CreditRecord{
+		{
+			// Incluído antes de ocorrer e com valor elevado
+			Documento:      "123.456.789-00",
+			Tipo:           "historico_credito",
+			Valor:          80000,
+			DataOcorrencia: referenceTime.AddDate(0, -2, 0),
+			DataInclusao:   referenceTime.AddDate(0, -3, 0),
+		},
+		{
+			// Registro de outro documento (piggybacking)
+			Documento:      "987.654.321-00",
+			Tipo:           "historico_credito",
+			Valor:          2000,
+			DataOcorrencia: referenceTime.AddDate(0, -1, 0),
+			DataInclusao:   referenceTime.AddDate(0, -1, 2),
+		},
+	}
+
+	risk := newEnricher().Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "123.456.789-00"},
+		Records: records,
+	})
+
+	assert.True(t, risk.SyntheticIdentity)
+	assert.ElementsMatch(t, []string{
+		fraudsignals.CodeThinFile,
+		fraudsignals.CodeInclusionBeforeFact,
+		fraudsignals.CodeHighValueThinFile,
+		fraudsignals.CodeForeignDocument,
+	}, signalCodes(risk))
+	// 1 − (0,85 × 0,55 × 0,6 × 0,65) ≈ 0,82
+	assert.Equal(t, 82, risk.Score)
+	assert.Equal(t, fraudsignals.LevelHigh, risk.Level)
+	assert.Contains(t, risk.Reasons, "Histórico escasso e inconsistente: indícios de identidade sintética")
+	for _, signal := range risk.Signals {
+		assert.Equal(t, fraudsignals.SourceHeuristic, signal.Source)
+		assert.Equal(t, fraudsignals.SignalSynthetic, signal.Type)
+	}
+}
+
+func TestHeuristicsSkippedWithoutHistory(t *testing.T) {
+	enricher := newEnricher()
+
+	withoutHistory := enricher.Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+	})
+	assert.Empty(t, withoutHistory.Signals, "consultas sem histórico (ex.: score) não são avaliadas pelas heurísticas")
+
+	emptyHistory := enricher.Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: []fraudsignals.CreditRecord{},
+	})
+	assert.Equal(t, []string{fraudsignals.CodeThinFile}, signalCodes(emptyHistory))
+}
+
+func TestDormantAndFutureRecordsAreInconsistencies(t *testing.T) {
+	dormant := newEnricher().Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: []fraudsignals.CreditRecord{{
+			Documento:      "12345678900",
+			Valor:          500,
+			DataOcorrencia: referenceTime.AddDate(-4, 0, 0),
+			DataInclusao:   referenceTime.AddDate(-4, 0, 1),
+		}},
+	})
+	assert.True(t, dormant.SyntheticIdentity)
+	assert.Contains(t, signalCodes(dormant), fraudsignals.CodeDormantHistory)
+
+	future := newEnricher().Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: []fraudsignals.CreditRecord{{
+			Documento:      "12345678900",
+			Valor:          500,
+			DataOcorrencia: referenceTime.AddDate(0, 1, 0),
+			DataInclusao:   referenceTime.AddDate(0, 1, 1),
+		}},
+	})
+	assert.True(t, future.SyntheticIdentity)
+	assert.Contains(t, signalCodes(future), fraudsignals.CodeFutureOccurrence)
+}
+
+func TestProviderSignalsAreMergedAndCombined(t *testing.T) {
+	device := &stubProvider{name: "device-intel", signalType: fraudsignals.SignalDevice, signals: []fraudsignals.Signal{
+		{Code: "emulator", Score: 50, Description: "Dispositivo emulado"},
+	}}
+	email := &stubProvider{name: "email-intel", signalType: fraudsignals.SignalEmail, signals: []fraudsignals.Signal{
+		{Code: "disposable_domain", Score: 150},
+	}}
+
+	risk := newEnricher(device, email).Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{
+			TenantID:      "tenant-1",
+			Documento:     "12345678900",
+			DispositivoID: "dev-1",
+			Email:         "cliente@example.com",
+		},
+		Records: establishedHistory(),
+	})
+
+	require.Len(t, risk.Signals, 2)
+	assert.Equal(t, "device-intel", risk.Signals[0].Source)
+	assert.Equal(t, fraudsignals.SignalDevice, risk.Signals[0].Type)
+	assert.Equal(t, "email-intel", risk.Signals[1].Source)
+	assert.Equal(t, 100, risk.Signals[1].Score, "o score dos provedores é limitado a 100")
+	assert.Equal(t, 100, risk.Score)
+	assert.Equal(t, fraudsignals.LevelCritical, risk.Level)
+	assert.Equal(t, []string{"Dispositivo emulado"}, risk.Reasons)
+}
+
+func TestCombinedScoreIsBoundedProbability(t *testing.T) {
+	phone := &stubProvider{name: "phone-intel", signalType: fraudsignals.SignalPhone, signals: []fraudsignals.Signal{
+		{Code: "voip", Score: 40},
+		{Code: "recently_ported", Score: 20},
+	}}
+
+	risk := newEnricher(phone).Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900", Telefone: "+244900000000"},
+		Records: establishedHistory(),
+	})
+
+	// 1 − (0,6 × 0,8) = 0,52
+	assert.Equal(t, 52, risk.Score)
+	assert.Equal(t, fraudsignals.LevelMedium, risk.Level)
+}
+
+func TestProvidersWithoutSubjectDataAreSkipped(t *testing.T) {
+	phone := &stubProvider{name: "phone-intel", signalType: fraudsignals.SignalPhone, signals: []fraudsignals.Signal{{Code: "voip", Score: 40}}}
+	registry := prometheus.NewRegistry()
+	enricher := newEnricher(phone)
+	enricher.SetMetrics(fraudsignals.NewMetrics(registry))
+
+	risk := enricher.Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: establishedHistory(),
+	})
+
+	assert.Equal(t, 0, phone.calls)
+	assert.Empty(t, risk.Signals)
+	assert.Empty(t, risk.FailedProviders)
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, "bureau_credito_fraude_provedor_chamadas_total"))
+}
+
+func TestFailingProvidersDoNotBlockEnrichment(t *testing.T) {
+	failing := &stubProvider{name: "email-intel", signalType: fraudsignals.SignalEmail, err: errors.New("indisponível")}
+	slow := &stubProvider{name: "device-intel", signalType: fraudsignals.SignalDevice, delay: time.Second}
+	config := fraudsignals.DefaultConfig()
+	config.ProviderTimeout = 20 * time.Millisecond
+	enricher := fraudsignals.NewEnricher(config, failing, slow)
+	enricher.SetClock(func() time.Time { return referenceTime })
+
+	started := time.Now()
+	risk := enricher.Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{
+			TenantID:  "tenant-1",
+			Documento: "12345678900",
+			Email:     "cliente@example.com",
+			IP:        "10.0.0.1",
+		},
+		Records: establishedHistory()[:1],
+	})
+
+	assert.Less(t, time.Since(started), 500*time.Millisecond, "o prazo dos provedores deve ser respeitado")
+	assert.Equal(t, []string{"email-intel", "device-intel"}, risk.FailedProviders)
+	assert.Equal(t, []string{fraudsignals.CodeThinFile}, signalCodes(risk))
+}
+
+func TestMetricsRecordRiskAndSignals(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	enricher := newEnricher()
+	enricher.SetMetrics(fraudsignals.NewMetrics(registry))
+
+	enricher.Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{TenantID: "tenant-1", Documento: "12345678900"},
+		Records: []fraudsignals.CreditRecord{{
+			Documento:      "12345678900",
+			Valor:          500,
+			DataOcorrencia: referenceTime.AddDate(0, 1, 0),
+		}},
+	})
+
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range metrics {
+		for _, metric := range family.GetMetric() {
+			if metric.GetCounter() != nil {
+				key := family.GetName()
+				for _, label := range metric.GetLabel() {
+					key += "," + label.GetName() + "=" + label.GetValue()
+				}
+				values[key] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, 1.0, values["bureau_credito_fraude_avaliacoes_total,identidade_sintetica=true,nivel=medio"])
+	assert.Equal(t, 1.0, values["bureau_credito_fraude_sinais_total,codigo=future_occurrence,tipo=synthetic"])
+	assert.Equal(t, 1.0, values["bureau_credito_fraude_sinais_total,codigo=thin_file,tipo=synthetic"])
+}
+
+func TestHTTPProviderSendsOnlyCategoryData(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "segredo", r.Header.Get(fraudsignals.APIKeyHeader))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"signals": []map[string]interface{}{{"code": "voip", "score": 40, "description": "Número VoIP"}},
+		})
+	}))
+	defer server.Close()
+
+	provider := fraudsignals.NewHTTPProvider(server.Client(), fraudsignals.HTTPProviderConfig{
+		Type:     fraudsignals.SignalPhone,
+		Endpoint: server.URL,
+		APIKey:   "segredo",
+	})
+	assert.Equal(t, "phone", provider.Name())
+
+	risk := newEnricher(provider).Enrich(context.Background(), fraudsignals.Request{
+		Subject: fraudsignals.Subject{
+			TenantID:  "tenant-1",
+			Market:    "angola",
+			Documento: "12345678900",
+			Telefone:  "+244900000000",
+			Email:     "cliente@example.com",
+		},
+		Records: establishedHistory(),
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"tenantId": "tenant-1",
+		"market":   "angola",
+		"telefone": "+244900000000",
+	}, received, "o documento e os restantes contactos não são enviados ao provedor")
+	require.Len(t, risk.Signals, 1)
+	assert.Equal(t, "phone", risk.Signals[0].Source)
+	assert.Equal(t, fraudsignals.SignalPhone, risk.Signals[0].Type)
+	assert.Equal(t, 40, risk.Score)
+}
+
+func TestHTTPProviderErrorStatusFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	provider := fraudsignals.NewHTTPProvider(nil, fraudsignals.HTTPProviderConfig{
+		Name:     "email-intel",
+		Type:     fraudsignals.SignalEmail,
+		Endpoint: server.URL,
+	})
+	_, err := provider.Evaluate(context.Background(), fraudsignals.Subject{Email: "cliente@example.com"})
+	assert.ErrorContains(t, err, "respondeu 503")
+}
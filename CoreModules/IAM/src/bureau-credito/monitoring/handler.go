@@ -0,0 +1,151 @@
+/**
+ * @file handler.go
+ * @description API HTTP das subscrições de monitorização do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package monitoring
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TenantHeader identifica o credor autenticado pelo gateway
+const TenantHeader = "X-Tenant-ID"
+
+// subscriptionRequest é o corpo de POST /monitoring/subscriptions
+type subscriptionRequest struct {
+	Documento       string      `json:"documento"`
+	TipoEntidade    string      `json:"tipoEntidade,omitempty"`
+	Market          string      `json:"market"`
+	ConsentimentoID string      `json:"consentimentoId,omitempty"`
+	Eventos         []EventType `json:"eventos"`
+	LimiarScore     int         `json:"limiarScore,omitempty"`
+	Intervalo       string      `json:"intervalo,omitempty"` // Duração Go, por exemplo "24h"
+	WebhookURL      string      `json:"webhookUrl"`
+}
+
+// Handler expõe a API de subscrições:
+//
+//	POST   /monitoring/subscriptions       cria uma subscrição (devolve o segredo uma única vez)
+//	GET    /monitoring/subscriptions       lista as subscrições do tenant
+//	GET    /monitoring/subscriptions/{id}  obtém uma subscrição
+//	DELETE /monitoring/subscriptions/{id}  remove uma subscrição
+type Handler struct {
+	monitor *Monitor
+}
+
+// NewHandler cria o handler da API de subscrições
+func NewHandler(monitor *Monitor) *Handler {
+	return &Handler{monitor: monitor}
+}
+
+// Register regista as rotas no multiplexador
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/monitoring/subscriptions", h)
+	mux.Handle("/monitoring/subscriptions/", h)
+}
+
+// ServeHTTP encaminha o pedido pelo caminho e pelo método
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get(TenantHeader)
+	if tenantID == "" {
+		http.Error(w, "cabeçalho "+TenantHeader+" obrigatório", http.StatusUnauthorized)
+		return
+	}
+
+	subscriptionID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/monitoring/subscriptions"), "/")
+	switch {
+	case subscriptionID == "" && r.Method == http.MethodPost:
+		h.create(w, r, tenantID)
+	case subscriptionID == "" && r.Method == http.MethodGet:
+		subscriptions, err := h.monitor.List(r.Context(), tenantID)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, subscriptions)
+	case subscriptionID != "" && r.Method == http.MethodGet:
+		subscription, err := h.monitor.Get(r.Context(), tenantID, subscriptionID)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, subscription)
+	case subscriptionID != "" && r.Method == http.MethodDelete:
+		if err := h.monitor.Unsubscribe(r.Context(), tenantID, subscriptionID); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// create regista uma subscrição do tenant
+func (h *Handler) create(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var req subscriptionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "corpo inválido", http.StatusBadRequest)
+		return
+	}
+
+	var intervalo time.Duration
+	if req.Intervalo != "" {
+		parsed, err := time.ParseDuration(req.Intervalo)
+		if err != nil {
+			http.Error(w, "intervalo inválido", http.StatusBadRequest)
+			return
+		}
+		intervalo = parsed
+	}
+
+	result, err := h.monitor.Subscribe(r.Context(), SubscribeRequest{
+		TenantID:        tenantID,
+		Documento:       req.Documento,
+		TipoEntidade:    req.TipoEntidade,
+		Market:          req.Market,
+		ConsentimentoID: req.ConsentimentoID,
+		Eventos:         req.Eventos,
+		LimiarScore:     req.LimiarScore,
+		Intervalo:       intervalo,
+		WebhookURL:      req.WebhookURL,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// writeError converte os erros da monitorização em respostas HTTP
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidSubscription):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrSubscriptionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrConsentRequired), errors.Is(err, ErrConsentInvalid):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Error().Err(err).Msg("Erro na API de monitorização")
+		http.Error(w, "erro interno", http.StatusInternalServerError)
+	}
+}
+
+// writeJSON serializa a resposta JSON
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error().Err(err).Msg("Erro ao serializar resposta da API de monitorização")
+	}
+}
@@ -0,0 +1,249 @@
+/**
+ * @file models.go
+ * @description Modelos das subscrições de monitorização contínua do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType identifica o tipo de alteração notificada ao credor
+type EventType string
+
+const (
+	EventNovaRestricao         EventType = "NOVA_RESTRICAO"
+	EventScoreAlterado         EventType = "SCORE_ALTERADO"
+	EventMonitorizacaoSuspensa EventType = "MONITORIZACAO_SUSPENSA" // Sempre notificado, sem dados de crédito
+)
+
+// IsValid indica se o tipo de evento pode ser subscrito
+func (t EventType) IsValid() bool {
+	return t == EventNovaRestricao || t == EventScoreAlterado
+}
+
+// SubscriptionStatus define o estado de uma subscrição
+type SubscriptionStatus string
+
+const (
+	StatusActive    SubscriptionStatus = "ACTIVE"
+	StatusSuspended SubscriptionStatus = "SUSPENDED" // Consentimento revogado ou expirado
+)
+
+// Erros da monitorização
+var (
+	ErrSubscriptionNotFound = errors.New("subscrição de monitorização não encontrada")
+	ErrInvalidSubscription  = errors.New("subscrição de monitorização inválida")
+	ErrConsentRequired      = errors.New("consentimento obrigatório para monitorização no mercado")
+	ErrConsentInvalid       = errors.New("consentimento inválido ou expirado")
+)
+
+// Restricao é uma restrição de crédito registada para o documento
+type Restricao struct {
+	ID             string    `json:"id"`
+	Tipo           string    `json:"tipo"`
+	Valor          float64   `json:"valor"`
+	FonteNome      string    `json:"fonteNome,omitempty"`
+	DataOcorrencia time.Time `json:"dataOcorrencia"`
+}
+
+// Snapshot é o estado do documento obtido numa consulta periódica
+type Snapshot struct {
+	Score        *int        `json:"score,omitempty"`
+	Restricoes   []Restricao `json:"restricoes,omitempty"`
+	ConsultadoEm time.Time   `json:"consultadoEm"`
+}
+
+// Subscription regista o interesse de um credor nas alterações de um documento
+type Subscription struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+
+	// Documento só é usado nas consultas; as respostas e os eventos levam a versão mascarada
+	Documento          string `json:"-"`
+	DocumentoMascarado string `json:"documento"`
+	TipoEntidade       string `json:"tipoEntidade,omitempty"` // PF ou PJ
+
+	Market          string      `json:"market"`
+	ConsentimentoID string      `json:"consentimentoId,omitempty"`
+	Eventos         []EventType `json:"eventos"`
+
+	// LimiarScore é a variação mínima, em pontos, que gera SCORE_ALTERADO
+	LimiarScore int           `json:"limiarScore"`
+	Intervalo   time.Duration `json:"intervalo"`
+
+	WebhookURL string `json:"webhookUrl"`
+	Secret     string `json:"-"` // Segredo HMAC das notificações
+
+	Status          SubscriptionStatus `json:"status"`
+	MotivoSuspensao string             `json:"motivoSuspensao,omitempty"`
+	UltimoErro      string             `json:"ultimoErro,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	LastCheckedAt   *time.Time         `json:"lastCheckedAt,omitempty"`
+	NextCheckAt     time.Time          `json:"nextCheckAt"`
+
+	// Referências da deteção: o score da última notificação (ou da primeira consulta) e as
+	// restrições já conhecidas; apenas avançam depois de o evento ser entregue
+	ScoreReferencia      *int     `json:"-"`
+	RestricoesConhecidas []string `json:"-"`
+	Inicializada         bool     `json:"-"`
+}
+
+// subscribes indica se a subscrição pediu o tipo de evento
+func (s *Subscription) subscribes(eventType EventType) bool {
+	for _, subscribed := range s.Eventos {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// clone copia a subscrição, incluindo as referências da deteção
+func (s *Subscription) clone() *Subscription {
+	copied := *s
+	copied.Eventos = append([]EventType(nil), s.Eventos...)
+	copied.RestricoesConhecidas = append([]string(nil), s.RestricoesConhecidas...)
+	if s.ScoreReferencia != nil {
+		score := *s.ScoreReferencia
+		copied.ScoreReferencia = &score
+	}
+	if s.LastCheckedAt != nil {
+		checked := *s.LastCheckedAt
+		copied.LastCheckedAt = &checked
+	}
+	return &copied
+}
+
+// Event é a notificação enviada ao webhook do credor
+type Event struct {
+	ID              string      `json:"id"`
+	Tipo            EventType   `json:"tipo"`
+	SubscriptionID  string      `json:"subscriptionId"`
+	TenantID        string      `json:"tenantId"`
+	Documento       string      `json:"documento"` // Mascarado
+	Market          string      `json:"market"`
+	OcorridoEm      time.Time   `json:"ocorridoEm"`
+	ScoreAnterior   *int        `json:"scoreAnterior,omitempty"`
+	ScoreAtual      *int        `json:"scoreAtual,omitempty"`
+	Variacao        int         `json:"variacao,omitempty"`
+	NovasRestricoes []Restricao `json:"novasRestricoes,omitempty"`
+	Motivo          string      `json:"motivo,omitempty"`
+}
+
+// Store define a persistência das subscrições
+type Store interface {
+	// Save cria ou atualiza uma subscrição
+	Save(ctx context.Context, subscription *Subscription) error
+
+	// Get recupera uma subscrição do tenant
+	Get(ctx context.Context, tenantID, subscriptionID string) (*Subscription, error)
+
+	// List lista as subscrições do tenant, por ordem de criação
+	List(ctx context.Context, tenantID string) ([]*Subscription, error)
+
+	// Due lista as subscrições ativas cuja próxima verificação já passou
+	Due(ctx context.Context, now time.Time, limit int) ([]*Subscription, error)
+
+	// Delete remove uma subscrição do tenant
+	Delete(ctx context.Context, tenantID, subscriptionID string) error
+}
+
+// InMemoryStore mantém as subscrições em memória
+type InMemoryStore struct {
+	subscriptions map[string]*Subscription
+	mutex         sync.RWMutex
+}
+
+// NewInMemoryStore cria um armazenamento de subscrições em memória
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{subscriptions: make(map[string]*Subscription)}
+}
+
+// Save grava uma cópia da subscrição
+func (s *InMemoryStore) Save(ctx context.Context, subscription *Subscription) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions[subscription.ID] = subscription.clone()
+	return nil
+}
+
+// Get retorna uma cópia da subscrição
+func (s *InMemoryStore) Get(ctx context.Context, tenantID, subscriptionID string) (*Subscription, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	subscription, ok := s.subscriptions[subscriptionID]
+	if !ok || subscription.TenantID != tenantID {
+		return nil, ErrSubscriptionNotFound
+	}
+	return subscription.clone(), nil
+}
+
+// List retorna cópias das subscrições do tenant
+func (s *InMemoryStore) List(ctx context.Context, tenantID string) ([]*Subscription, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var subscriptions []*Subscription
+	for _, subscription := range s.subscriptions {
+		if subscription.TenantID == tenantID {
+			subscriptions = append(subscriptions, subscription.clone())
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+// Due retorna cópias das subscrições ativas a verificar, as mais atrasadas primeiro
+func (s *InMemoryStore) Due(ctx context.Context, now time.Time, limit int) ([]*Subscription, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var subscriptions []*Subscription
+	for _, subscription := range s.subscriptions {
+		if subscription.Status == StatusActive && !subscription.NextCheckAt.After(now) {
+			subscriptions = append(subscriptions, subscription.clone())
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].NextCheckAt.Before(subscriptions[j].NextCheckAt)
+	})
+	if limit > 0 && len(subscriptions) > limit {
+		subscriptions = subscriptions[:limit]
+	}
+	return subscriptions, nil
+}
+
+// Delete remove a subscrição
+func (s *InMemoryStore) Delete(ctx context.Context, tenantID, subscriptionID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subscription, ok := s.subscriptions[subscriptionID]
+	if !ok || subscription.TenantID != tenantID {
+		return ErrSubscriptionNotFound
+	}
+	delete(s.subscriptions, subscriptionID)
+	return nil
+}
+
+// maskDocumento mantém apenas os dois últimos dígitos do documento
+func maskDocumento(documento string) string {
+	documento = strings.TrimSpace(documento)
+	if len(documento) <= 2 {
+		return strings.Repeat("*", len(documento))
+	}
+	return strings.Repeat("*", len(documento)-2) + documento[len(documento)-2:]
+}
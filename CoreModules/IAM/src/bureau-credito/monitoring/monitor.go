@@ -0,0 +1,533 @@
+/**
+ * @file monitor.go
+ * @description Monitorização contínua de documentos com deteção de novas restrições e variações de score
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package monitoring
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Valores padrão da monitorização
+const (
+	DefaultPollInterval = time.Minute
+	DefaultBatchSize    = 100
+	DefaultIntervalo    = 24 * time.Hour
+	DefaultMinIntervalo = time.Hour
+	DefaultLimiarScore  = 50
+)
+
+// Consultor executa a consulta periódica de um documento monitorizado
+type Consultor interface {
+	ConsultarMonitorizacao(ctx context.Context, subscription *Subscription) (*Snapshot, error)
+}
+
+// ConsentVerifier valida o consentimento do titular do documento no mercado
+type ConsentVerifier interface {
+	VerificarConsentimento(ctx context.Context, market, documento, consentimentoID string) (bool, error)
+}
+
+// Config define a monitorização contínua
+type Config struct {
+	PollInterval time.Duration `json:"pollInterval"` // Frequência com que as subscrições vencidas são procuradas
+	BatchSize    int           `json:"batchSize"`    // Subscrições verificadas por ciclo
+	MinIntervalo time.Duration `json:"minIntervalo"` // Intervalo mínimo entre consultas de uma subscrição
+
+	// ConsentimentoObrigatorio indica, por mercado em minúsculas, se a monitorização exige
+	// consentimento; os mercados sem entrada usam ConsentimentoPadrao
+	ConsentimentoObrigatorio map[string]bool `json:"consentimentoObrigatorio"`
+	ConsentimentoPadrao      bool            `json:"consentimentoPadrao"`
+
+	// PermitirHTTP aceita webhooks sem TLS, apenas para desenvolvimento
+	PermitirHTTP bool `json:"permitirHttp"`
+}
+
+// DefaultConfig retorna a configuração padrão da monitorização
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:        DefaultPollInterval,
+		BatchSize:           DefaultBatchSize,
+		MinIntervalo:        DefaultMinIntervalo,
+		ConsentimentoPadrao: true,
+	}
+}
+
+// consentRequired indica se o mercado exige consentimento para a monitorização
+func (c Config) consentRequired(market string) bool {
+	if required, ok := c.ConsentimentoObrigatorio[strings.ToLower(market)]; ok {
+		return required
+	}
+	return c.ConsentimentoPadrao
+}
+
+// SubscribeRequest representa o pedido de monitorização de um documento
+type SubscribeRequest struct {
+	TenantID        string        `json:"tenantId"`
+	Documento       string        `json:"documento"`
+	TipoEntidade    string        `json:"tipoEntidade,omitempty"`
+	Market          string        `json:"market"`
+	ConsentimentoID string        `json:"consentimentoId,omitempty"`
+	Eventos         []EventType   `json:"eventos"`
+	LimiarScore     int           `json:"limiarScore,omitempty"`
+	Intervalo       time.Duration `json:"intervalo,omitempty"`
+	WebhookURL      string        `json:"webhookUrl"`
+}
+
+// SubscribeResult devolve a subscrição criada e o segredo das notificações, mostrado uma única vez
+type SubscribeResult struct {
+	Subscription *Subscription `json:"subscription"`
+	Secret       string        `json:"secret"`
+}
+
+// Monitor verifica periodicamente os documentos subscritos, compara cada consulta com
+// a anterior e notifica os credores das alterações pedidas
+type Monitor struct {
+	store     Store
+	consultor Consultor
+	consent   ConsentVerifier
+	notifier  Notifier
+	config    Config
+	metrics   *Metrics
+	now       func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor cria o monitor; consent pode ser nil quando nenhum mercado exige consentimento
+func NewMonitor(store Store, consultor Consultor, consent ConsentVerifier, notifier Notifier, config Config) *Monitor {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MinIntervalo <= 0 {
+		config.MinIntervalo = defaults.MinIntervalo
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		store:     store,
+		consultor: consultor,
+		consent:   consent,
+		notifier:  notifier,
+		config:    config,
+		now:       time.Now,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetClock substitui o relógio usado no agendamento das verificações
+func (m *Monitor) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// SetMetrics define as métricas Prometheus da monitorização
+func (m *Monitor) SetMetrics(metrics *Metrics) {
+	m.metrics = metrics
+}
+
+// Start inicia o worker que verifica as subscrições vencidas
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := m.RunOnce(m.ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Error().Err(err).Msg("Erro no ciclo de monitorização do Bureau de Crédito")
+				}
+			}
+		}
+	}()
+}
+
+// Stop interrompe o worker
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Subscribe valida o pedido e o consentimento e regista a subscrição; a primeira
+// verificação serve de referência e não gera eventos
+func (m *Monitor) Subscribe(ctx context.Context, req SubscribeRequest) (*SubscribeResult, error) {
+	req.Documento = strings.TrimSpace(req.Documento)
+	req.Market = strings.TrimSpace(req.Market)
+	if req.TenantID == "" || req.Documento == "" || req.Market == "" {
+		return nil, fmt.Errorf("%w: tenant, documento e mercado são obrigatórios", ErrInvalidSubscription)
+	}
+	if len(req.Eventos) == 0 {
+		return nil, fmt.Errorf("%w: pelo menos um tipo de evento é obrigatório", ErrInvalidSubscription)
+	}
+	for _, eventType := range req.Eventos {
+		if !eventType.IsValid() {
+			return nil, fmt.Errorf("%w: tipo de evento desconhecido %q", ErrInvalidSubscription, eventType)
+		}
+	}
+	if req.LimiarScore < 0 {
+		return nil, fmt.Errorf("%w: limiar de score negativo", ErrInvalidSubscription)
+	}
+	if req.LimiarScore == 0 {
+		req.LimiarScore = DefaultLimiarScore
+	}
+	if req.Intervalo == 0 {
+		req.Intervalo = DefaultIntervalo
+	}
+	if req.Intervalo < m.config.MinIntervalo {
+		return nil, fmt.Errorf("%w: intervalo mínimo de %s", ErrInvalidSubscription, m.config.MinIntervalo)
+	}
+	if err := m.validateWebhookURL(req.WebhookURL); err != nil {
+		return nil, err
+	}
+	if err := m.verifyConsent(ctx, req.Market, req.Documento, req.ConsentimentoID); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar segredo da subscrição: %w", err)
+	}
+
+	now := m.now()
+	subscription := &Subscription{
+		ID:                 uuid.New().String(),
+		TenantID:           req.TenantID,
+		Documento:          req.Documento,
+		DocumentoMascarado: maskDocumento(req.Documento),
+		TipoEntidade:       req.TipoEntidade,
+		Market:             req.Market,
+		ConsentimentoID:    req.ConsentimentoID,
+		Eventos:            append([]EventType(nil), req.Eventos...),
+		LimiarScore:        req.LimiarScore,
+		Intervalo:          req.Intervalo,
+		WebhookURL:         req.WebhookURL,
+		Secret:             secret,
+		Status:             StatusActive,
+		CreatedAt:          now,
+		NextCheckAt:        now,
+	}
+	if err := m.store.Save(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("erro ao gravar subscrição: %w", err)
+	}
+
+	log.Info().
+		Str("subscription_id", subscription.ID).
+		Str("tenant_id", subscription.TenantID).
+		Str("market", subscription.Market).
+		Str("documento", subscription.DocumentoMascarado).
+		Msg("Subscrição de monitorização criada")
+
+	return &SubscribeResult{Subscription: subscription, Secret: secret}, nil
+}
+
+// Get retorna uma subscrição do tenant
+func (m *Monitor) Get(ctx context.Context, tenantID, subscriptionID string) (*Subscription, error) {
+	return m.store.Get(ctx, tenantID, subscriptionID)
+}
+
+// List retorna as subscrições do tenant
+func (m *Monitor) List(ctx context.Context, tenantID string) ([]*Subscription, error) {
+	return m.store.List(ctx, tenantID)
+}
+
+// Unsubscribe remove a subscrição; nenhuma consulta é feita depois
+func (m *Monitor) Unsubscribe(ctx context.Context, tenantID, subscriptionID string) error {
+	if err := m.store.Delete(ctx, tenantID, subscriptionID); err != nil {
+		return err
+	}
+	log.Info().Str("subscription_id", subscriptionID).Str("tenant_id", tenantID).Msg("Subscrição de monitorização removida")
+	return nil
+}
+
+// RunOnce verifica as subscrições vencidas e retorna quantas foram verificadas
+func (m *Monitor) RunOnce(ctx context.Context) (int, error) {
+	due, err := m.store.Due(ctx, m.now(), m.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao listar subscrições vencidas: %w", err)
+	}
+	for _, subscription := range due {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		m.check(ctx, subscription)
+	}
+	return len(due), nil
+}
+
+// check verifica o consentimento, consulta o documento e notifica as alterações
+func (m *Monitor) check(ctx context.Context, subscription *Subscription) {
+	now := m.now()
+	subscription.LastCheckedAt = &now
+	subscription.NextCheckAt = now.Add(subscription.Intervalo)
+
+	// O consentimento é verificado antes de cada consulta: revogado ou expirado, a subscrição é suspensa
+	if err := m.verifyConsent(ctx, subscription.Market, subscription.Documento, subscription.ConsentimentoID); err != nil {
+		if errors.Is(err, ErrConsentRequired) || errors.Is(err, ErrConsentInvalid) {
+			m.suspend(ctx, subscription, err)
+			return
+		}
+		m.fail(ctx, subscription, err)
+		return
+	}
+
+	snapshot, err := m.consultor.ConsultarMonitorizacao(ctx, subscription)
+	if err != nil {
+		m.fail(ctx, subscription, fmt.Errorf("erro na consulta de monitorização: %w", err))
+		return
+	}
+	m.metrics.observeCheck(subscription.Market, "ok")
+
+	for _, event := range m.detect(subscription, snapshot) {
+		if err := m.notifier.Notify(ctx, subscription, event); err != nil {
+			// As referências não avançam: a alteração volta a ser detetada na próxima verificação
+			m.metrics.observeEvent(subscription.Market, event.Tipo, "failed")
+			m.fail(ctx, subscription, err)
+			return
+		}
+		m.metrics.observeEvent(subscription.Market, event.Tipo, "delivered")
+		log.Info().
+			Str("subscription_id", subscription.ID).
+			Str("event_id", event.ID).
+			Str("event_type", string(event.Tipo)).
+			Msg("Alteração de crédito notificada")
+	}
+
+	m.advance(subscription, snapshot)
+	subscription.UltimoErro = ""
+	m.save(ctx, subscription)
+}
+
+// detect compara a consulta com as referências da subscrição
+func (m *Monitor) detect(subscription *Subscription, snapshot *Snapshot) []Event {
+	if !subscription.Inicializada {
+		return nil
+	}
+
+	var events []Event
+	if subscription.subscribes(EventNovaRestricao) {
+		known := make(map[string]bool, len(subscription.RestricoesConhecidas))
+		for _, id := range subscription.RestricoesConhecidas {
+			known[id] = true
+		}
+		var novas []Restricao
+		for _, restricao := range snapshot.Restricoes {
+			if !known[restricao.ID] {
+				novas = append(novas, restricao)
+			}
+		}
+		if len(novas) > 0 {
+			event := m.newEvent(subscription, EventNovaRestricao)
+			event.NovasRestricoes = novas
+			events = append(events, event)
+		}
+	}
+
+	if subscription.subscribes(EventScoreAlterado) && subscription.ScoreReferencia != nil && snapshot.Score != nil {
+		variacao := *snapshot.Score - *subscription.ScoreReferencia
+		if abs(variacao) >= subscription.LimiarScore {
+			event := m.newEvent(subscription, EventScoreAlterado)
+			anterior, atual := *subscription.ScoreReferencia, *snapshot.Score
+			event.ScoreAnterior = &anterior
+			event.ScoreAtual = &atual
+			event.Variacao = variacao
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// advance atualiza as referências depois de entregues os eventos; o score de referência só muda
+// quando a variação foi notificada, para que variações pequenas e sucessivas também sejam detetadas
+func (m *Monitor) advance(subscription *Subscription, snapshot *Snapshot) {
+	ids := make([]string, 0, len(snapshot.Restricoes))
+	for _, restricao := range snapshot.Restricoes {
+		ids = append(ids, restricao.ID)
+	}
+	subscription.RestricoesConhecidas = ids
+
+	if snapshot.Score != nil {
+		if subscription.ScoreReferencia == nil || !subscription.Inicializada ||
+			abs(*snapshot.Score-*subscription.ScoreReferencia) >= subscription.LimiarScore {
+			score := *snapshot.Score
+			subscription.ScoreReferencia = &score
+		}
+	}
+	subscription.Inicializada = true
+}
+
+// suspend suspende a subscrição e avisa o credor, sem dados de crédito
+func (m *Monitor) suspend(ctx context.Context, subscription *Subscription, reason error) {
+	subscription.Status = StatusSuspended
+	subscription.MotivoSuspensao = reason.Error()
+	m.metrics.observeCheck(subscription.Market, "suspended")
+
+	log.Warn().
+		Str("subscription_id", subscription.ID).
+		Str("tenant_id", subscription.TenantID).
+		Str("market", subscription.Market).
+		Err(reason).
+		Msg("Subscrição de monitorização suspensa por falta de consentimento")
+
+	event := m.newEvent(subscription, EventMonitorizacaoSuspensa)
+	event.Motivo = reason.Error()
+	if err := m.notifier.Notify(ctx, subscription, event); err != nil {
+		subscription.UltimoErro = err.Error()
+		m.metrics.observeEvent(subscription.Market, event.Tipo, "failed")
+	} else {
+		m.metrics.observeEvent(subscription.Market, event.Tipo, "delivered")
+	}
+	m.save(ctx, subscription)
+}
+
+// fail regista o erro da verificação; a subscrição volta a ser verificada no intervalo seguinte
+func (m *Monitor) fail(ctx context.Context, subscription *Subscription, err error) {
+	subscription.UltimoErro = err.Error()
+	m.metrics.observeCheck(subscription.Market, "error")
+	log.Error().
+		Str("subscription_id", subscription.ID).
+		Str("tenant_id", subscription.TenantID).
+		Err(err).
+		Msg("Falha na verificação de monitorização")
+	m.save(ctx, subscription)
+}
+
+// save grava a subscrição, exceto se tiver sido removida durante a verificação
+func (m *Monitor) save(ctx context.Context, subscription *Subscription) {
+	if _, err := m.store.Get(ctx, subscription.TenantID, subscription.ID); err != nil {
+		return
+	}
+	if err := m.store.Save(ctx, subscription); err != nil {
+		log.Error().Err(err).Str("subscription_id", subscription.ID).Msg("Erro ao gravar subscrição de monitorização")
+	}
+}
+
+// verifyConsent exige um consentimento válido quando o mercado o torna obrigatório
+func (m *Monitor) verifyConsent(ctx context.Context, market, documento, consentimentoID string) error {
+	if !m.config.consentRequired(market) {
+		return nil
+	}
+	if consentimentoID == "" {
+		return fmt.Errorf("%w %s", ErrConsentRequired, market)
+	}
+	if m.consent == nil {
+		return fmt.Errorf("%w: verificação de consentimento não configurada", ErrConsentInvalid)
+	}
+	valid, err := m.consent.VerificarConsentimento(ctx, market, documento, consentimentoID)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar consentimento: %w", err)
+	}
+	if !valid {
+		return ErrConsentInvalid
+	}
+	return nil
+}
+
+// validateWebhookURL exige um URL absoluto com HTTPS (ou HTTP quando permitido)
+func (m *Monitor) validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("%w: webhookUrl inválido", ErrInvalidSubscription)
+	}
+	if parsed.Scheme != "https" && !(m.config.PermitirHTTP && parsed.Scheme == "http") {
+		return fmt.Errorf("%w: webhookUrl tem de usar HTTPS", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// newEvent cria um evento da subscrição
+func (m *Monitor) newEvent(subscription *Subscription, eventType EventType) Event {
+	return Event{
+		ID:             uuid.New().String(),
+		Tipo:           eventType,
+		SubscriptionID: subscription.ID,
+		TenantID:       subscription.TenantID,
+		Documento:      subscription.DocumentoMascarado,
+		Market:         subscription.Market,
+		OcorridoEm:     m.now(),
+	}
+}
+
+// newSecret gera o segredo HMAC de uma subscrição
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Metrics contém as métricas Prometheus da monitorização
+type Metrics struct {
+	checksCounter *prometheus.CounterVec
+	eventsCounter *prometheus.CounterVec
+}
+
+// NewMetrics cria e regista as métricas da monitorização
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		checksCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_monitoring_checks_total",
+				Help: "Número total de verificações de monitorização pelo resultado (ok, error, suspended)",
+			},
+			[]string{"market", "result"},
+		),
+		eventsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_monitoring_events_total",
+				Help: "Número total de eventos de monitorização pelo tipo e pelo resultado da entrega",
+			},
+			[]string{"market", "event_type", "delivery"},
+		),
+	}
+
+	registry.MustRegister(m.checksCounter, m.eventsCounter)
+	return m
+}
+
+// observeCheck regista o resultado de uma verificação
+func (m *Metrics) observeCheck(market, result string) {
+	if m == nil {
+		return
+	}
+	m.checksCounter.WithLabelValues(market, result).Inc()
+}
+
+// observeEvent regista a entrega de um evento
+func (m *Metrics) observeEvent(market string, eventType EventType, delivery string) {
+	if m == nil {
+		return
+	}
+	m.eventsCounter.WithLabelValues(market, string(eventType), delivery).Inc()
+}
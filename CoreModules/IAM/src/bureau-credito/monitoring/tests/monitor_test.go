@@ -0,0 +1,480 @@
+/**
+ * @file monitor_test.go
+ * @description Testes da monitorização contínua e das notificações assinadas do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/monitoring"
+)
+
+// testClock é um relógio ajustável para o agendamento das verificações
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeConsultor devolve o estado configurado do documento
+type fakeConsultor struct {
+	mutex    sync.Mutex
+	snapshot monitoring.Snapshot
+	err      error
+	calls    int
+}
+
+func (c *fakeConsultor) ConsultarMonitorizacao(ctx context.Context, subscription *monitoring.Subscription) (*monitoring.Snapshot, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	snapshot := c.snapshot
+	snapshot.Restricoes = append([]monitoring.Restricao(nil), c.snapshot.Restricoes...)
+	return &snapshot, nil
+}
+
+func (c *fakeConsultor) set(score int, restricoes ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.snapshot = monitoring.Snapshot{Score: &score}
+	for _, id := range restricoes {
+		c.snapshot.Restricoes = append(c.snapshot.Restricoes, monitoring.Restricao{ID: id, Tipo: "inadimplencia", Valor: 500})
+	}
+}
+
+func (c *fakeConsultor) callCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.calls
+}
+
+// fakeConsent aceita apenas os consentimentos registados
+type fakeConsent struct {
+	mutex sync.Mutex
+	valid map[string]bool
+}
+
+func (c *fakeConsent) VerificarConsentimento(ctx context.Context, market, documento, consentimentoID string) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.valid[consentimentoID], nil
+}
+
+func (c *fakeConsent) revoke(consentimentoID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.valid, consentimentoID)
+}
+
+// fakeNotifier regista os eventos entregues
+type fakeNotifier struct {
+	mutex  sync.Mutex
+	events []monitoring.Event
+	err    error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, subscription *monitoring.Subscription, event monitoring.Event) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.err != nil {
+		return n.err
+	}
+	n.events = append(n.events, event)
+	return nil
+}
+
+func (n *fakeNotifier) setError(err error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.err = err
+}
+
+func (n *fakeNotifier) delivered() []monitoring.Event {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return append([]monitoring.Event(nil), n.events...)
+}
+
+type monitorFixture struct {
+	monitor   *monitoring.Monitor
+	consultor *fakeConsultor
+	consent   *fakeConsent
+	notifier  *fakeNotifier
+	clock     *testClock
+}
+
+func newMonitorFixture() *monitorFixture {
+	f := &monitorFixture{
+		consultor: &fakeConsultor{},
+		consent:   &fakeConsent{valid: map[string]bool{"consent-1": true}},
+		notifier:  &fakeNotifier{},
+		clock:     &testClock{now: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	config := monitoring.DefaultConfig()
+	config.ConsentimentoObrigatorio = map[string]bool{"brazil": true, "usa": false}
+	f.monitor = monitoring.NewMonitor(monitoring.NewInMemoryStore(), f.consultor, f.consent, f.notifier, config)
+	f.monitor.SetClock(f.clock.Now)
+	return f
+}
+
+func validRequest() monitoring.SubscribeRequest {
+	return monitoring.SubscribeRequest{
+		TenantID:        "credor-1",
+		Documento:       "123.456.789-00",
+		Market:          "Brazil",
+		ConsentimentoID: "consent-1",
+		Eventos:         []monitoring.EventType{monitoring.EventNovaRestricao, monitoring.EventScoreAlterado},
+		LimiarScore:     40,
+		Intervalo:       24 * time.Hour,
+		WebhookURL:      "https://credor.example.com/webhooks/bureau",
+	}
+}
+
+// runCycle avança até à próxima verificação e executa um ciclo
+func (f *monitorFixture) runCycle(t *testing.T) {
+	f.clock.Advance(24 * time.Hour)
+	_, err := f.monitor.RunOnce(context.Background())
+	require.NoError(t, err)
+}
+
+func TestSubscribeValidation(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	t.Run("consentimento obrigatório no mercado", func(t *testing.T) {
+		req := validRequest()
+		req.ConsentimentoID = ""
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.ErrorIs(t, err, monitoring.ErrConsentRequired)
+	})
+
+	t.Run("consentimento inválido", func(t *testing.T) {
+		req := validRequest()
+		req.ConsentimentoID = "consent-desconhecido"
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.ErrorIs(t, err, monitoring.ErrConsentInvalid)
+	})
+
+	t.Run("mercado sem consentimento obrigatório", func(t *testing.T) {
+		req := validRequest()
+		req.Market = "USA"
+		req.ConsentimentoID = ""
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("webhook sem HTTPS", func(t *testing.T) {
+		req := validRequest()
+		req.WebhookURL = "http://credor.example.com/webhooks"
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.ErrorIs(t, err, monitoring.ErrInvalidSubscription)
+	})
+
+	t.Run("evento desconhecido", func(t *testing.T) {
+		req := validRequest()
+		req.Eventos = []monitoring.EventType{monitoring.EventMonitorizacaoSuspensa}
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.ErrorIs(t, err, monitoring.ErrInvalidSubscription)
+	})
+
+	t.Run("intervalo abaixo do mínimo", func(t *testing.T) {
+		req := validRequest()
+		req.Intervalo = time.Minute
+		_, err := f.monitor.Subscribe(ctx, req)
+		assert.ErrorIs(t, err, monitoring.ErrInvalidSubscription)
+	})
+
+	t.Run("documento mascarado", func(t *testing.T) {
+		result, err := f.monitor.Subscribe(ctx, validRequest())
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Secret)
+
+		body, err := json.Marshal(result.Subscription)
+		require.NoError(t, err)
+		assert.NotContains(t, string(body), "123.456.789-00")
+		assert.NotContains(t, string(body), result.Secret)
+		assert.Equal(t, "************00", result.Subscription.DocumentoMascarado)
+	})
+}
+
+func TestChangeDetection(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	result, err := f.monitor.Subscribe(ctx, validRequest())
+	require.NoError(t, err)
+
+	// A primeira verificação apenas estabelece a referência
+	f.consultor.set(700, "RES-1")
+	_, err = f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, f.notifier.delivered())
+
+	// Antes do intervalo a subscrição não volta a ser consultada
+	_, err = f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.consultor.callCount())
+
+	// Nova restrição
+	f.consultor.set(700, "RES-1", "RES-2")
+	f.runCycle(t)
+	events := f.notifier.delivered()
+	require.Len(t, events, 1)
+	assert.Equal(t, monitoring.EventNovaRestricao, events[0].Tipo)
+	require.Len(t, events[0].NovasRestricoes, 1)
+	assert.Equal(t, "RES-2", events[0].NovasRestricoes[0].ID)
+	assert.Equal(t, result.Subscription.ID, events[0].SubscriptionID)
+	assert.Equal(t, "************00", events[0].Documento)
+
+	// Variações abaixo do limiar acumulam-se até o ultrapassarem
+	f.consultor.set(680, "RES-1", "RES-2")
+	f.runCycle(t)
+	assert.Len(t, f.notifier.delivered(), 1)
+
+	f.consultor.set(655, "RES-1", "RES-2")
+	f.runCycle(t)
+	events = f.notifier.delivered()
+	require.Len(t, events, 2)
+	assert.Equal(t, monitoring.EventScoreAlterado, events[1].Tipo)
+	assert.Equal(t, 700, *events[1].ScoreAnterior)
+	assert.Equal(t, 655, *events[1].ScoreAtual)
+	assert.Equal(t, -45, events[1].Variacao)
+
+	// Sem alterações, sem eventos
+	f.runCycle(t)
+	assert.Len(t, f.notifier.delivered(), 2)
+}
+
+func TestOnlySubscribedEvents(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	req := validRequest()
+	req.Eventos = []monitoring.EventType{monitoring.EventScoreAlterado}
+	_, err := f.monitor.Subscribe(ctx, req)
+	require.NoError(t, err)
+
+	f.consultor.set(700)
+	_, err = f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+
+	f.consultor.set(700, "RES-9")
+	f.runCycle(t)
+	assert.Empty(t, f.notifier.delivered())
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	result, err := f.monitor.Subscribe(ctx, validRequest())
+	require.NoError(t, err)
+	f.consultor.set(700)
+	_, err = f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+
+	f.notifier.setError(errors.New("webhook indisponível"))
+	f.consultor.set(700, "RES-1")
+	f.runCycle(t)
+
+	subscription, err := f.monitor.Get(ctx, "credor-1", result.Subscription.ID)
+	require.NoError(t, err)
+	assert.Contains(t, subscription.UltimoErro, "webhook indisponível")
+
+	// A restrição não entregue volta a ser detetada na verificação seguinte
+	f.notifier.setError(nil)
+	f.runCycle(t)
+	events := f.notifier.delivered()
+	require.Len(t, events, 1)
+	assert.Equal(t, "RES-1", events[0].NovasRestricoes[0].ID)
+
+	subscription, err = f.monitor.Get(ctx, "credor-1", result.Subscription.ID)
+	require.NoError(t, err)
+	assert.Empty(t, subscription.UltimoErro)
+}
+
+func TestRevokedConsentSuspendsSubscription(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	result, err := f.monitor.Subscribe(ctx, validRequest())
+	require.NoError(t, err)
+	f.consultor.set(700)
+	_, err = f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+
+	f.consent.revoke("consent-1")
+	f.consultor.set(500, "RES-1")
+	f.runCycle(t)
+
+	events := f.notifier.delivered()
+	require.Len(t, events, 1)
+	assert.Equal(t, monitoring.EventMonitorizacaoSuspensa, events[0].Tipo)
+	assert.Nil(t, events[0].ScoreAtual)
+	assert.Empty(t, events[0].NovasRestricoes)
+	assert.Equal(t, 1, f.consultor.callCount())
+
+	subscription, err := f.monitor.Get(ctx, "credor-1", result.Subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, monitoring.StatusSuspended, subscription.Status)
+
+	// Suspensa, a subscrição deixa de ser consultada
+	f.runCycle(t)
+	assert.Equal(t, 1, f.consultor.callCount())
+}
+
+func TestUnsubscribe(t *testing.T) {
+	f := newMonitorFixture()
+	ctx := context.Background()
+
+	result, err := f.monitor.Subscribe(ctx, validRequest())
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.monitor.Unsubscribe(ctx, "outro-credor", result.Subscription.ID), monitoring.ErrSubscriptionNotFound)
+	require.NoError(t, f.monitor.Unsubscribe(ctx, "credor-1", result.Subscription.ID))
+
+	checked, err := f.monitor.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, checked)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		received [][]byte
+		headers  []http.Header
+		attempts int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, body)
+		headers = append(headers, r.Header.Clone())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := monitoring.NewWebhookNotifier(server.Client(), monitoring.WebhookConfig{MaxAttempts: 3, RetryDelay: time.Millisecond})
+	subscription := &monitoring.Subscription{ID: "sub-1", WebhookURL: server.URL, Secret: "segredo-do-credor"}
+	event := monitoring.Event{ID: "evt-1", Tipo: monitoring.EventNovaRestricao, SubscriptionID: "sub-1"}
+
+	require.NoError(t, notifier.Notify(context.Background(), subscription, event))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 2, attempts)
+	require.Len(t, received, 1)
+	assert.Equal(t, "NOVA_RESTRICAO", headers[0].Get(monitoring.EventHeader))
+	assert.Equal(t, "evt-1", headers[0].Get(monitoring.DeliveryHeader))
+
+	signature := headers[0].Get(monitoring.SignatureHeader)
+	assert.NoError(t, monitoring.VerifySignature("segredo-do-credor", signature, received[0], 5*time.Minute, time.Now()))
+	assert.Error(t, monitoring.VerifySignature("outro-segredo", signature, received[0], 5*time.Minute, time.Now()))
+	assert.Error(t, monitoring.VerifySignature("segredo-do-credor", signature, bytes.ToUpper(received[0]), 5*time.Minute, time.Now()))
+	assert.Error(t, monitoring.VerifySignature("segredo-do-credor", signature, received[0], 5*time.Minute, time.Now().Add(time.Hour)))
+}
+
+func TestWebhookClientErrorIsNotRetried(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		attempts int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		attempts++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	notifier := monitoring.NewWebhookNotifier(server.Client(), monitoring.WebhookConfig{MaxAttempts: 3, RetryDelay: time.Millisecond})
+	err := notifier.Notify(context.Background(), &monitoring.Subscription{WebhookURL: server.URL, Secret: "s"}, monitoring.Event{ID: "evt-1"})
+	assert.Error(t, err)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 1, attempts)
+}
+
+func TestSubscriptionAPI(t *testing.T) {
+	f := newMonitorFixture()
+	mux := http.NewServeMux()
+	monitoring.NewHandler(f.monitor).Register(mux)
+
+	body := `{"documento":"123.456.789-00","market":"brazil","consentimentoId":"consent-1",` +
+		`"eventos":["NOVA_RESTRICAO"],"intervalo":"12h","webhookUrl":"https://credor.example.com/hook"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/monitoring/subscriptions", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/monitoring/subscriptions", bytes.NewBufferString(body))
+	req.Header.Set(monitoring.TenantHeader, "credor-1")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created monitoring.SubscribeResult
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, 12*time.Hour, created.Subscription.Intervalo)
+
+	req = httptest.NewRequest(http.MethodGet, "/monitoring/subscriptions/"+created.Subscription.ID, nil)
+	req.Header.Set(monitoring.TenantHeader, "outro-credor")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/monitoring/subscriptions/"+created.Subscription.ID, nil)
+	req.Header.Set(monitoring.TenantHeader, "credor-1")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	body = `{"documento":"123","market":"brazil","eventos":["NOVA_RESTRICAO"],"webhookUrl":"https://credor.example.com/hook"}`
+	req = httptest.NewRequest(http.MethodPost, "/monitoring/subscriptions", bytes.NewBufferString(body))
+	req.Header.Set(monitoring.TenantHeader, "credor-1")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,170 @@
+/**
+ * @file webhook.go
+ * @description Entrega assinada das notificações de monitorização aos webhooks dos credores
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cabeçalhos das notificações, iguais aos dos webhooks do gateway de pagamentos
+const (
+	SignatureHeader = "X-Innovabiz-Signature" // t=<unix>,v1=<hex(hmac_sha256(segredo, t + "." + corpo))>
+	EventHeader     = "X-Innovabiz-Event"
+	DeliveryHeader  = "X-Innovabiz-Delivery"
+)
+
+// Valores padrão da entrega
+const (
+	DefaultWebhookTimeout     = 10 * time.Second
+	DefaultWebhookMaxAttempts = 3
+	DefaultWebhookRetryDelay  = time.Second
+)
+
+// Notifier entrega os eventos ao credor
+type Notifier interface {
+	Notify(ctx context.Context, subscription *Subscription, event Event) error
+}
+
+// SignPayload calcula o cabeçalho de assinatura do corpo da notificação
+func SignPayload(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// VerifySignature valida o cabeçalho de assinatura recebido pelo credor, rejeitando
+// notificações assinadas há mais de tolerance (proteção contra reenvio)
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	for _, part := range strings.Split(header, ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(part), "t="); ok {
+			timestamp = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("assinatura sem timestamp válido")
+	}
+	signedAt := time.Unix(unix, 0)
+	if tolerance > 0 && (now.Sub(signedAt) > tolerance || signedAt.Sub(now) > tolerance) {
+		return fmt.Errorf("assinatura fora da janela de tolerância")
+	}
+	if !hmac.Equal([]byte(SignPayload(secret, signedAt, body)), []byte(strings.TrimSpace(header))) {
+		return fmt.Errorf("assinatura inválida")
+	}
+	return nil
+}
+
+// WebhookConfig define a entrega das notificações
+type WebhookConfig struct {
+	Timeout     time.Duration `json:"timeout"`
+	MaxAttempts int           `json:"maxAttempts"` // Incluindo a primeira
+	RetryDelay  time.Duration `json:"retryDelay"`  // Duplica a cada nova tentativa
+}
+
+// DefaultWebhookConfig retorna a configuração padrão da entrega
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout:     DefaultWebhookTimeout,
+		MaxAttempts: DefaultWebhookMaxAttempts,
+		RetryDelay:  DefaultWebhookRetryDelay,
+	}
+}
+
+// WebhookNotifier envia os eventos por HTTP POST assinados com o segredo da subscrição
+type WebhookNotifier struct {
+	client *http.Client
+	config WebhookConfig
+	now    func() time.Time
+}
+
+// NewWebhookNotifier cria o notificador; client pode ser nil
+func NewWebhookNotifier(client *http.Client, config WebhookConfig) *WebhookNotifier {
+	defaults := DefaultWebhookConfig()
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryDelay < 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	return &WebhookNotifier{client: client, config: config, now: time.Now}
+}
+
+// Notify entrega o evento, repetindo as falhas de rede e as respostas 5xx ou 429
+func (n *WebhookNotifier) Notify(ctx context.Context, subscription *Subscription, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+
+	delay := n.config.RetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.deliver(ctx, subscription, event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.config.MaxAttempts {
+			return fmt.Errorf("entrega do evento %s falhou após %d tentativa(s): %w", event.ID, attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// deliver faz uma tentativa de entrega e indica se a falha pode ser repetida
+func (n *WebhookNotifier) deliver(ctx context.Context, subscription *Subscription, event Event, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, SignPayload(subscription.Secret, n.now(), body))
+	req.Header.Set(EventHeader, string(event.Tipo))
+	req.Header.Set(DeliveryHeader, event.ID)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook respondeu %d", resp.StatusCode)
+}
@@ -16,7 +16,11 @@ import (
 	"innovabiz/iam/src/bureau-credito/adapters"
 	"innovabiz/iam/src/bureau-credito/fraud-detection"
 	"innovabiz/iam/src/bureau-credito/orchestration/models"
+	"innovabiz/iam/src/bureau-credito/residency"
 	"innovabiz/iam/src/bureau-credito/risk-engine"
+	"innovabiz/iam/src/bureau-credito/routing"
+	"innovabiz/iam/src/bureau-credito/sla"
+	"innovabiz/iam/src/bureau-credito/telemetry"
 )
 
 // performIdentityAssessment executa a avaliação de identidade
@@ -63,12 +67,31 @@ func (o *BureauOrchestrator) performCreditAssessment(
 		creditProviders = o.config.DefaultCreditProviders
 	}
 
+	// Provedores degradados por violação de SLA passam para o fim da ordem de preferência
+	if o.slaMonitor != nil {
+		creditProviders = o.slaMonitor.Prioritize(append([]string(nil), creditProviders...))
+	}
+
+	// Com roteamento por custo, apenas os provedores que satisfazem a consulta são consultados
+	if o.costRouter != nil {
+		routed, err := o.routeCreditProviders(ctx, request, creditProviders)
+		if err != nil {
+			return err
+		}
+		creditProviders = routed
+	}
+
 	// Criar resposta para resultados de crédito
 	creditResults := &models.CreditResults{
 		ProviderResponses: make(map[string]adapters.CreditReportResponse),
 		ReportDate:        time.Now(),
 	}
 
+	// Com hedging, os provedores são consultados pela ordem de preferência e vence a primeira resposta
+	if o.config.Hedging.Enabled && len(creditProviders) > 1 {
+		return o.performHedgedCreditAssessment(ctx, request, response, resultMutex, creditProviders, creditResults)
+	}
+
 	// Para cada provedor, obter relatório de crédito
 	var wg sync.WaitGroup
 	var providerMutex sync.Mutex
@@ -89,38 +112,19 @@ func (o *BureauOrchestrator) performCreditAssessment(
 				return
 			}
 
-			// Criar solicitação de relatório de crédito
-			reportRequest := adapters.CreditReportRequest{
-				UserID:           request.UserID,
-				TenantID:         request.TenantID,
-				DocumentNumber:   request.IdentityData.DocumentNumber,
-				DocumentType:     request.IdentityData.DocumentType,
-				Name:             request.IdentityData.Name,
-				DateOfBirth:      request.IdentityData.DateOfBirth,
-				Nationality:      request.IdentityData.Nationality,
-				Email:            request.IdentityData.Email,
-				PhoneNumber:      request.IdentityData.PhoneNumber,
-				Address:          request.IdentityData.Address,
-				ContextData:      request.CustomAttributes,
-				RequestTimestamp: time.Now(),
-			}
-
-			// Adicionar dados de crédito se disponíveis
-			if request.CreditData != nil {
-				reportRequest.AnnualIncome = request.CreditData.AnnualIncome
-				reportRequest.Occupation = request.CreditData.Occupation
-				reportRequest.EmploymentStatus = request.CreditData.EmploymentStatus
-				reportRequest.HasPendingLoans = request.CreditData.HasPendingLoans
-			}
-
 			// Solicitar relatório de crédito
-			reportResponse, err := creditProvider.GetCreditReport(ctx, reportRequest)
+			spanCtx, span := telemetry.StartProviderSpan(ctx, provider)
+			startedAt := time.Now()
+			reportResponse, err := creditProvider.GetCreditReport(spanCtx, newCreditReportRequest(request))
+			o.recordProviderSLA(provider, startedAt, err)
+			telemetry.EndSpan(span, err)
 			if err != nil {
 				providerMutex.Lock()
 				providerErrors = append(providerErrors, fmt.Sprintf("%s: %s", provider, err.Error()))
 				providerMutex.Unlock()
 				return
 			}
+			o.chargeConsulta(request, provider)
 
 			// Adicionar resposta ao resultado
 			providerMutex.Lock()
@@ -152,6 +156,151 @@ func (o *BureauOrchestrator) performCreditAssessment(
 	return nil
 }
 
+// performHedgedCreditAssessment obtém o relatório de crédito com hedging entre os provedores
+func (o *BureauOrchestrator) performHedgedCreditAssessment(
+	ctx context.Context,
+	request *models.AssessmentRequest,
+	response *models.AssessmentResponse,
+	resultMutex *sync.Mutex,
+	creditProviders []string,
+	creditResults *models.CreditResults,
+) error {
+	reportRequest := newCreditReportRequest(request)
+
+	result, err := ExecuteHedged(ctx, o.config.Hedging, o.hedgingMetrics, creditProviders,
+		func(ctx context.Context, provider string) (*adapters.CreditReportResponse, error) {
+			creditProvider, err := o.getCreditProvider(provider)
+			if err != nil {
+				return nil, err
+			}
+			ctx, span := telemetry.StartProviderSpan(ctx, provider)
+			startedAt := time.Now()
+			report, err := creditProvider.GetCreditReport(ctx, reportRequest)
+			o.recordProviderSLA(provider, startedAt, err)
+			telemetry.EndSpan(span, err)
+			if err == nil {
+				o.chargeConsulta(request, provider)
+			}
+			return report, err
+		})
+	if err != nil {
+		return err
+	}
+
+	creditResults.ProviderResponses[result.Provider] = *result.Response
+	o.consolidateCreditResults(creditResults)
+
+	// Atualizar resposta com resultados de crédito
+	resultMutex.Lock()
+	response.CreditResults = creditResults
+	response.DataSources = append(response.DataSources, "CREDIT_ASSESSMENT", "CREDIT_"+result.Provider)
+	resultMutex.Unlock()
+
+	return nil
+}
+
+// routeCreditProviders restringe os provedores aos que suportam o tipo de consulta e devolvem os
+// campos exigidos, do mais barato ao mais caro; sem hedging apenas o mais barato é consultado
+func (o *BureauOrchestrator) routeCreditProviders(
+	ctx context.Context,
+	request *models.AssessmentRequest,
+	creditProviders []string,
+) ([]string, error) {
+	var degraded []string
+	if o.slaMonitor != nil {
+		for _, provider := range creditProviders {
+			if o.slaMonitor.Status(provider).Tier == sla.TierDegraded {
+				degraded = append(degraded, provider)
+			}
+		}
+	}
+
+	decision, err := o.costRouter.Route(ctx, routing.Requirement{
+		ConsultaID: request.RequestID,
+		TenantID:   request.TenantID,
+		Tipo:       request.TipoConsulta,
+		Fields:     request.RequiredFields,
+		Candidates: creditProviders,
+		Degraded:   degraded,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha no roteamento por custo: %w", err)
+	}
+
+	if !o.config.Hedging.Enabled {
+		return decision.Providers[:1], nil
+	}
+	return decision.Providers, nil
+}
+
+// routeResidency decide, pela residência dos dados do documento, se a consulta é processada localmente
+// Retorna proxied quando a consulta foi encaminhada e a resposta é a da instância regional
+func (o *BureauOrchestrator) routeResidency(
+	ctx context.Context,
+	request *models.AssessmentRequest,
+) (*models.AssessmentResponse, bool, error) {
+	subject := residency.Subject{
+		ConsultaID:    request.RequestID,
+		TenantID:      request.TenantID,
+		Market:        request.Market,
+		ForwardedFrom: residency.ForwardedFrom(ctx),
+	}
+	if request.IdentityData != nil {
+		subject.DocumentType = request.IdentityData.DocumentType
+		subject.Nationality = request.IdentityData.Nationality
+	}
+
+	decision, err := o.residencyRouter.Route(ctx, subject)
+	if err != nil {
+		return nil, false, fmt.Errorf("consulta recusada pela residência dos dados: %w", err)
+	}
+	if decision.Outcome != residency.OutcomeProxied {
+		return nil, false, nil
+	}
+
+	response := &models.AssessmentResponse{}
+	if err := o.residencyProxy.Forward(ctx, decision, request, response); err != nil {
+		return nil, true, fmt.Errorf("falha no encaminhamento para a região %s: %w", decision.TargetRegion, err)
+	}
+	return response, true, nil
+}
+
+// chargeConsulta regista a tarifa de uma consulta concluída no gasto do tenant
+func (o *BureauOrchestrator) chargeConsulta(request *models.AssessmentRequest, provider string) {
+	if o.costRouter == nil {
+		return
+	}
+	o.costRouter.Charge(request.TenantID, request.TipoConsulta, provider)
+}
+
+// newCreditReportRequest cria a solicitação de relatório de crédito a partir da avaliação
+func newCreditReportRequest(request *models.AssessmentRequest) adapters.CreditReportRequest {
+	reportRequest := adapters.CreditReportRequest{
+		UserID:           request.UserID,
+		TenantID:         request.TenantID,
+		DocumentNumber:   request.IdentityData.DocumentNumber,
+		DocumentType:     request.IdentityData.DocumentType,
+		Name:             request.IdentityData.Name,
+		DateOfBirth:      request.IdentityData.DateOfBirth,
+		Nationality:      request.IdentityData.Nationality,
+		Email:            request.IdentityData.Email,
+		PhoneNumber:      request.IdentityData.PhoneNumber,
+		Address:          request.IdentityData.Address,
+		ContextData:      request.CustomAttributes,
+		RequestTimestamp: time.Now(),
+	}
+
+	// Adicionar dados de crédito se disponíveis
+	if request.CreditData != nil {
+		reportRequest.AnnualIncome = request.CreditData.AnnualIncome
+		reportRequest.Occupation = request.CreditData.Occupation
+		reportRequest.EmploymentStatus = request.CreditData.EmploymentStatus
+		reportRequest.HasPendingLoans = request.CreditData.HasPendingLoans
+	}
+
+	return reportRequest
+}
+
 // performFraudAssessment executa a avaliação de fraude
 func (o *BureauOrchestrator) performFraudAssessment(
 	ctx context.Context,
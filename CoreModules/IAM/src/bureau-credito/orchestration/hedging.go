@@ -0,0 +1,303 @@
+/**
+ * @file hedging.go
+ * @description Requisições com hedging para provedores de crédito lentos
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"innovabiz/iam/src/bureau-credito/adapters"
+)
+
+// Papéis de uma tentativa de consulta com hedging
+const (
+	HedgeRolePrimary = "primary"
+	HedgeRoleHedge   = "hedge"
+)
+
+// Valores padrão de hedging
+const (
+	DefaultHedgeDelay       = 500 * time.Millisecond
+	DefaultHedgeMaxAttempts = 2
+)
+
+// HedgingConfig define o comportamento de hedging das consultas de crédito
+type HedgingConfig struct {
+	// Enabled ativa o hedging; desativado, todos os provedores são consultados em paralelo
+	Enabled bool `json:"enabled"`
+
+	// Delay é o tempo de espera por um provedor antes de consultar o próximo
+	Delay time.Duration `json:"delay"`
+
+	// ProviderDelays sobrepõe Delay por provedor, permitindo ajustar cada um pela sua latência
+	ProviderDelays map[string]time.Duration `json:"providerDelays,omitempty"`
+
+	// MaxAttempts limita o número de provedores consultados por consulta (incluindo o primário)
+	MaxAttempts int `json:"maxAttempts"`
+}
+
+// delayFor retorna o tempo de espera antes de fazer hedge de um provedor
+func (c HedgingConfig) delayFor(provider string) time.Duration {
+	if delay, exists := c.ProviderDelays[provider]; exists && delay > 0 {
+		return delay
+	}
+	if c.Delay > 0 {
+		return c.Delay
+	}
+	return DefaultHedgeDelay
+}
+
+// maxAttempts retorna o número máximo de tentativas para a lista de provedores
+func (c HedgingConfig) maxAttempts(providers int) int {
+	limit := c.MaxAttempts
+	if limit <= 0 {
+		limit = DefaultHedgeMaxAttempts
+	}
+	if limit > providers {
+		limit = providers
+	}
+	return limit
+}
+
+// HedgingMetrics contém as métricas Prometheus usadas para ajustar os atrasos de hedging
+type HedgingMetrics struct {
+	consultasCounter *prometheus.CounterVec
+	hedgesCounter    *prometheus.CounterVec
+	winsCounter      *prometheus.CounterVec
+	wastedCounter    *prometheus.CounterVec
+	attemptHist      *prometheus.HistogramVec
+}
+
+// NewHedgingMetrics cria e regista as métricas de hedging
+func NewHedgingMetrics(registry *prometheus.Registry) *HedgingMetrics {
+	m := &HedgingMetrics{
+		// Taxa de hedge = bureau_credito_hedges_total / bureau_credito_hedged_consultas_total
+		consultasCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_hedged_consultas_total",
+				Help: "Número total de consultas executadas com hedging, por provedor primário",
+			},
+			[]string{"provider"},
+		),
+		hedgesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_hedges_total",
+				Help: "Número total de consultas de hedge emitidas, por provedor atrasado e provedor de hedge",
+			},
+			[]string{"provider", "hedge_provider"},
+		),
+		winsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_hedge_wins_total",
+				Help: "Número total de respostas vencedoras por provedor e papel na consulta",
+			},
+			[]string{"provider", "role"},
+		),
+		wastedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_hedge_wasted_seconds_total",
+				Help: "Tempo consumido por tentativas descartadas ou canceladas após outra resposta vencer",
+			},
+			[]string{"provider"},
+		),
+		attemptHist: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "bureau_credito_hedge_attempt_duration_seconds",
+				Help:    "Duração das tentativas de consulta com hedging por provedor e resultado",
+				Buckets: prometheus.ExponentialBuckets(0.05, 2, 10), // 50ms a ~25s
+			},
+			[]string{"provider", "outcome"},
+		),
+	}
+
+	registry.MustRegister(m.consultasCounter, m.hedgesCounter, m.winsCounter, m.wastedCounter, m.attemptHist)
+	return m
+}
+
+// observeConsulta regista uma consulta iniciada com hedging
+func (m *HedgingMetrics) observeConsulta(provider string) {
+	if m == nil {
+		return
+	}
+	m.consultasCounter.WithLabelValues(provider).Inc()
+}
+
+// observeHedge regista a emissão de uma consulta de hedge
+func (m *HedgingMetrics) observeHedge(provider, hedgeProvider string) {
+	if m == nil {
+		return
+	}
+	m.hedgesCounter.WithLabelValues(provider, hedgeProvider).Inc()
+}
+
+// observeAttempt regista a duração e o resultado de uma tentativa
+func (m *HedgingMetrics) observeAttempt(attempt hedgeAttempt, outcome string) {
+	if m == nil {
+		return
+	}
+	m.attemptHist.WithLabelValues(attempt.provider, outcome).Observe(attempt.duration.Seconds())
+	switch outcome {
+	case "won":
+		m.winsCounter.WithLabelValues(attempt.provider, attempt.role()).Inc()
+	case "wasted":
+		m.wastedCounter.WithLabelValues(attempt.provider).Add(attempt.duration.Seconds())
+	}
+}
+
+// ProviderCall executa a consulta de crédito num provedor
+type ProviderCall func(ctx context.Context, provider string) (*adapters.CreditReportResponse, error)
+
+// HedgeResult contém a resposta vencedora de uma consulta com hedging
+type HedgeResult struct {
+	Provider string
+	Response *adapters.CreditReportResponse
+	Attempts int  // Número de provedores consultados
+	Hedged   bool // Indica se foi emitida pelo menos uma consulta de hedge
+}
+
+// hedgeAttempt é o resultado de uma tentativa individual
+type hedgeAttempt struct {
+	index    int
+	provider string
+	response *adapters.CreditReportResponse
+	err      error
+	duration time.Duration
+}
+
+// role retorna o papel da tentativa na consulta
+func (a hedgeAttempt) role() string {
+	if a.index == 0 {
+		return HedgeRolePrimary
+	}
+	return HedgeRoleHedge
+}
+
+// ExecuteHedged consulta os provedores pela ordem de preferência: após o atraso configurado
+// para o provedor em curso (ou imediatamente, em caso de falha) consulta o próximo, retornando
+// a primeira resposta bem-sucedida e cancelando as restantes
+func ExecuteHedged(
+	ctx context.Context,
+	config HedgingConfig,
+	metrics *HedgingMetrics,
+	providers []string,
+	call ProviderCall,
+) (*HedgeResult, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("nenhum provedor de crédito configurado")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	maxAttempts := config.maxAttempts(len(providers))
+	results := make(chan hedgeAttempt, maxAttempts)
+	launched, inflight := 0, 0
+	hedged := false
+
+	launch := func() {
+		index := launched
+		provider := providers[index]
+		launched++
+		inflight++
+		go func() {
+			start := time.Now()
+			response, err := call(ctx, provider)
+			results <- hedgeAttempt{
+				index:    index,
+				provider: provider,
+				response: response,
+				err:      err,
+				duration: time.Since(start),
+			}
+		}()
+	}
+
+	// drainLosers contabiliza o trabalho desperdiçado das tentativas ainda em curso
+	drainLosers := func(pending int) {
+		go func() {
+			for i := 0; i < pending; i++ {
+				metrics.observeAttempt(<-results, "wasted")
+			}
+		}()
+	}
+
+	metrics.observeConsulta(providers[0])
+	launch()
+
+	timer := time.NewTimer(config.delayFor(providers[0]))
+	defer timer.Stop()
+
+	var failures []string
+	for {
+		timerC := timer.C
+		if launched >= maxAttempts {
+			timerC = nil
+		}
+
+		select {
+		case attempt := <-results:
+			inflight--
+			if attempt.err == nil && attempt.response != nil {
+				metrics.observeAttempt(attempt, "won")
+				cancel()
+				drainLosers(inflight)
+				return &HedgeResult{
+					Provider: attempt.provider,
+					Response: attempt.response,
+					Attempts: launched,
+					Hedged:   hedged,
+				}, nil
+			}
+
+			metrics.observeAttempt(attempt, "failed")
+
+			// Tentativa interrompida pelo contexto do chamador: não consulta outros provedores
+			if err := ctx.Err(); err != nil {
+				drainLosers(inflight)
+				return nil, fmt.Errorf("consulta com hedging interrompida: %w", err)
+			}
+
+			if attempt.err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %s", attempt.provider, attempt.err.Error()))
+			} else {
+				failures = append(failures, fmt.Sprintf("%s: resposta vazia", attempt.provider))
+			}
+
+			// Falha não espera pelo atraso: passa de imediato ao próximo provedor
+			if launched < maxAttempts {
+				launch()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(config.delayFor(providers[launched-1]))
+				continue
+			}
+			if inflight == 0 {
+				return nil, fmt.Errorf("falha em todos os provedores de crédito: %s", strings.Join(failures, "; "))
+			}
+
+		case <-timerC:
+			metrics.observeHedge(providers[launched-1], providers[launched])
+			hedged = true
+			launch()
+			timer.Reset(config.delayFor(providers[launched-1]))
+
+		case <-ctx.Done():
+			drainLosers(inflight)
+			return nil, fmt.Errorf("consulta com hedging interrompida: %w", ctx.Err())
+		}
+	}
+}
@@ -46,6 +46,7 @@ type AssessmentRequest struct {
 	CorrelationID   string            `json:"correlationId,omitempty"`
 	UserID          string            `json:"userId"`
 	TenantID        string            `json:"tenantId"`
+	Market          string            `json:"market,omitempty"` // Mercado da consulta, propagado no baggage
 	
 	// Metadados
 	RequestTimestamp time.Time         `json:"requestTimestamp"`
@@ -55,6 +56,8 @@ type AssessmentRequest struct {
 	// Configuração de avaliação
 	AssessmentTypes  []AssessmentType  `json:"assessmentTypes"`
 	CreditProviders  []string          `json:"creditProviders,omitempty"`
+	TipoConsulta     string            `json:"tipoConsulta,omitempty"`   // Tipo de consulta usado no roteamento por custo
+	RequiredFields   []string          `json:"requiredFields,omitempty"` // Campos de dados exigidos aos provedores de crédito
 	IdentityProviders []string         `json:"identityProviders,omitempty"`
 	ComplianceRules  []string          `json:"complianceRules,omitempty"`
 	
@@ -19,7 +19,11 @@ import (
 	"innovabiz/iam/src/bureau-credito/adapters"
 	"innovabiz/iam/src/bureau-credito/fraud-detection"
 	"innovabiz/iam/src/bureau-credito/orchestration/models"
+	"innovabiz/iam/src/bureau-credito/residency"
 	"innovabiz/iam/src/bureau-credito/risk-engine"
+	"innovabiz/iam/src/bureau-credito/routing"
+	"innovabiz/iam/src/bureau-credito/sla"
+	"innovabiz/iam/src/bureau-credito/telemetry"
 )
 
 // BureauOrchestrator coordena a execução de múltiplos serviços de avaliação
@@ -35,6 +39,11 @@ type BureauOrchestrator struct {
 	// Outros componentes
 	eventBus             EventBus
 	cache                CacheService
+	hedgingMetrics       *HedgingMetrics
+	slaMonitor           *sla.Monitor
+	costRouter           *routing.Router
+	residencyRouter      *residency.Router
+	residencyProxy       *residency.Proxy
 	
 	// Configurações
 	config               OrchestratorConfig
@@ -56,8 +65,18 @@ type OrchestratorConfig struct {
 	TelemetryEnabled          bool
 	MaxRetries                int
 	RetryDelayMs              int
+	Hedging                   HedgingConfig // Hedging de consultas entre provedores de crédito
 }
 
+// ErrSLAMonitorDisabled indica que a monitorização de SLA dos provedores não está configurada
+var ErrSLAMonitorDisabled = errors.New("monitorização de SLA dos provedores não configurada")
+
+// ErrCostRoutingDisabled indica que o roteamento por custo dos provedores não está configurado
+var ErrCostRoutingDisabled = errors.New("roteamento por custo dos provedores não configurado")
+
+// ErrResidencyRoutingDisabled indica que o roteamento de residência entre mercados não está configurado
+var ErrResidencyRoutingDisabled = errors.New("roteamento de residência entre mercados não configurado")
+
 // EventBus define a interface para publicação de eventos
 type EventBus interface {
 	// PublishEvent publica um evento para outros serviços
@@ -119,6 +138,86 @@ func NewBureauOrchestrator(
 	}
 }
 
+// SetHedgingMetrics define as métricas usadas nas consultas com hedging
+func (o *BureauOrchestrator) SetHedgingMetrics(metrics *HedgingMetrics) {
+	o.hedgingMetrics = metrics
+}
+
+// SetSLAMonitor define o monitor de SLA que ordena os provedores e regista as consultas
+func (o *BureauOrchestrator) SetSLAMonitor(monitor *sla.Monitor) {
+	o.slaMonitor = monitor
+}
+
+// GetProviderSLAStatus retorna o cumprimento do SLO por cada provedor monitorizado
+func (o *BureauOrchestrator) GetProviderSLAStatus() ([]sla.ProviderStatus, error) {
+	if o.slaMonitor == nil {
+		return nil, ErrSLAMonitorDisabled
+	}
+	return o.slaMonitor.Statuses(), nil
+}
+
+// GetProviderSLAStatusByID retorna o cumprimento do SLO por um provedor de crédito
+func (o *BureauOrchestrator) GetProviderSLAStatusByID(provider string) (*sla.ProviderStatus, error) {
+	if o.slaMonitor == nil {
+		return nil, ErrSLAMonitorDisabled
+	}
+	status := o.slaMonitor.Status(provider)
+	return &status, nil
+}
+
+// SetCostRouter define o roteador que escolhe o provedor de crédito mais barato para cada consulta
+func (o *BureauOrchestrator) SetCostRouter(router *routing.Router) {
+	o.costRouter = router
+}
+
+// GetRoutingDecisions retorna as decisões de roteamento por custo mais recentes do tenant
+func (o *BureauOrchestrator) GetRoutingDecisions(ctx context.Context, tenantID string, limit int) ([]routing.Decision, error) {
+	if o.costRouter == nil {
+		return nil, ErrCostRoutingDisabled
+	}
+	return o.costRouter.Decisions(ctx, tenantID, limit)
+}
+
+// GetTenantSpend retorna o gasto do tenant em consultas no mês corrente
+func (o *BureauOrchestrator) GetTenantSpend(tenantID string) (*routing.Spend, error) {
+	if o.costRouter == nil {
+		return nil, ErrCostRoutingDisabled
+	}
+	spend := o.costRouter.Spend(tenantID)
+	return &spend, nil
+}
+
+// SetResidencyRouter define o roteador que mantém as consultas na região do mercado do documento
+// As consultas de mercados processados noutra região são encaminhadas para a instância dessa região
+func (o *BureauOrchestrator) SetResidencyRouter(router *residency.Router) {
+	o.residencyRouter = router
+	o.residencyProxy = residency.NewProxy(router, o.config.DefaultTimeout)
+}
+
+// GetResidencyDecisions retorna as decisões de residência entre regiões mais recentes do tenant
+func (o *BureauOrchestrator) GetResidencyDecisions(ctx context.Context, tenantID string, limit int) ([]residency.Decision, error) {
+	if o.residencyRouter == nil {
+		return nil, ErrResidencyRoutingDisabled
+	}
+	return o.residencyRouter.Decisions(ctx, tenantID, limit)
+}
+
+// VerifyForwardedAssessment valida a assinatura de uma consulta encaminhada por outra região
+func (o *BureauOrchestrator) VerifyForwardedAssessment(from, signature string, body []byte) error {
+	if o.residencyRouter == nil {
+		return ErrResidencyRoutingDisabled
+	}
+	return o.residencyRouter.VerifyForwarded(from, signature, body, time.Now())
+}
+
+// recordProviderSLA regista a duração e o resultado de uma consulta no monitor de SLA
+func (o *BureauOrchestrator) recordProviderSLA(provider string, startedAt time.Time, err error) {
+	if o.slaMonitor == nil {
+		return
+	}
+	o.slaMonitor.Record(provider, time.Since(startedAt), err)
+}
+
 // RequestAssessment inicia uma nova avaliação orquestrada
 func (o *BureauOrchestrator) RequestAssessment(
 	ctx context.Context,
@@ -155,6 +254,20 @@ func (o *BureauOrchestrator) RequestAssessment(
 	ctx, cancel := context.WithTimeout(ctx, request.Timeout)
 	defer cancel()
 	
+	// Propagar a identificação da consulta até às chamadas aos provedores
+	ctx = telemetry.WithConsulta(ctx, telemetry.ConsultaContext{
+		ConsultaID: request.RequestID,
+		TenantID:   request.TenantID,
+		Market:     request.Market,
+	})
+	
+	// Garantir a residência dos dados do documento antes de qualquer processamento local
+	if o.residencyRouter != nil {
+		if response, proxied, err := o.routeResidency(ctx, &request); err != nil || proxied {
+			return response, err
+		}
+	}
+	
 	// Registrar solicitação ativa
 	o.registerActiveAssessment(request.RequestID, &request)
 	defer o.unregisterActiveAssessment(request.RequestID)
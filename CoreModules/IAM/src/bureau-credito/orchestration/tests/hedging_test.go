@@ -0,0 +1,255 @@
+/**
+ * @file hedging_test.go
+ * @description Testes das consultas de crédito com hedging entre provedores
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/adapters"
+	"innovabiz/iam/src/bureau-credito/orchestration"
+)
+
+// providerBehavior define a latência e o resultado simulados de um provedor
+type providerBehavior struct {
+	latency time.Duration
+	err     error
+}
+
+// providerCallRecord regista o início e o desfecho de uma chamada a um provedor
+type providerCallRecord struct {
+	startedAt time.Time
+	cancelled bool
+	finished  bool
+}
+
+// fakeProviders simula os provedores de crédito e regista as chamadas recebidas
+type fakeProviders struct {
+	mu        sync.Mutex
+	start     time.Time
+	behaviors map[string]providerBehavior
+	calls     map[string]*providerCallRecord
+}
+
+func newFakeProviders(behaviors map[string]providerBehavior) *fakeProviders {
+	return &fakeProviders{
+		start:     time.Now(),
+		behaviors: behaviors,
+		calls:     make(map[string]*providerCallRecord),
+	}
+}
+
+// call implementa orchestration.ProviderCall respeitando o cancelamento do contexto
+func (f *fakeProviders) call(ctx context.Context, provider string) (*adapters.CreditReportResponse, error) {
+	record := &providerCallRecord{startedAt: time.Now()}
+	f.mu.Lock()
+	f.calls[provider] = record
+	behavior := f.behaviors[provider]
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		record.finished = true
+		f.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(behavior.latency):
+	case <-ctx.Done():
+		f.mu.Lock()
+		record.cancelled = true
+		f.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	if behavior.err != nil {
+		return nil, behavior.err
+	}
+	return &adapters.CreditReportResponse{ProviderName: provider, CreditScore: 700}, nil
+}
+
+// called retorna o atraso do início da chamada ao provedor face ao início da consulta
+func (f *fakeProviders) called(provider string) (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, exists := f.calls[provider]
+	if !exists {
+		return 0, false
+	}
+	return record.startedAt.Sub(f.start), true
+}
+
+// cancelled indica se a chamada ao provedor foi cancelada antes de responder
+func (f *fakeProviders) cancelled(provider string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, exists := f.calls[provider]
+	return exists && record.cancelled
+}
+
+// wait aguarda o fim das chamadas esperadas, incluindo as perdedoras ainda em curso
+func (f *fakeProviders) wait(t *testing.T, calls int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		finished := 0
+		for _, record := range f.calls {
+			if record.finished {
+				finished++
+			}
+		}
+		return finished == calls
+	}, 2*time.Second, 5*time.Millisecond, "chamadas aos provedores não terminaram")
+}
+
+var hedgeProviders = []string{"serasa", "spc", "boavista"}
+
+func TestExecuteHedgedPrimaryWithinDelay(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa": {latency: 10 * time.Millisecond},
+	})
+
+	result, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{
+		Enabled: true,
+		Delay:   200 * time.Millisecond,
+	}, nil, hedgeProviders, providers.call)
+	require.NoError(t, err)
+	providers.wait(t, 1)
+
+	assert.Equal(t, "serasa", result.Provider)
+	assert.Equal(t, 1, result.Attempts)
+	assert.False(t, result.Hedged)
+	_, hedgeCalled := providers.called("spc")
+	assert.False(t, hedgeCalled, "sem atraso do primário não há hedge")
+}
+
+func TestExecuteHedgedHedgeAfterDelayCancelsLoser(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa": {latency: time.Second},
+		"spc":    {latency: 10 * time.Millisecond},
+	})
+
+	result, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{
+		Enabled:     true,
+		Delay:       50 * time.Millisecond,
+		MaxAttempts: 2,
+	}, orchestration.NewHedgingMetrics(prometheus.NewRegistry()), hedgeProviders, providers.call)
+	require.NoError(t, err)
+	providers.wait(t, 2)
+
+	assert.Equal(t, "spc", result.Provider)
+	assert.Equal(t, 2, result.Attempts)
+	assert.True(t, result.Hedged)
+
+	hedgeStart, called := providers.called("spc")
+	require.True(t, called)
+	assert.GreaterOrEqual(t, hedgeStart, 50*time.Millisecond, "o hedge só parte depois do atraso configurado")
+	assert.True(t, providers.cancelled("serasa"), "o primário perdedor deve ser cancelado")
+
+	_, called = providers.called("boavista")
+	assert.False(t, called, "MaxAttempts limita os provedores consultados")
+}
+
+func TestExecuteHedgedProviderDelayOverride(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa": {latency: time.Second},
+		"spc":    {latency: 10 * time.Millisecond},
+	})
+
+	result, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{
+		Enabled:        true,
+		Delay:          time.Second,
+		ProviderDelays: map[string]time.Duration{"serasa": 20 * time.Millisecond},
+	}, nil, hedgeProviders, providers.call)
+	require.NoError(t, err)
+	providers.wait(t, 2)
+
+	assert.Equal(t, "spc", result.Provider)
+	hedgeStart, _ := providers.called("spc")
+	assert.Less(t, hedgeStart, 500*time.Millisecond, "o atraso do provedor sobrepõe o atraso global")
+}
+
+func TestExecuteHedgedFailureSkipsDelay(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa": {err: errors.New("serviço indisponível")},
+		"spc":    {latency: 10 * time.Millisecond},
+	})
+
+	result, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{
+		Enabled: true,
+		Delay:   time.Second,
+	}, nil, hedgeProviders, providers.call)
+	require.NoError(t, err)
+	providers.wait(t, 2)
+
+	assert.Equal(t, "spc", result.Provider)
+	assert.False(t, result.Hedged, "a falha passa ao próximo provedor sem contar como hedge")
+	hedgeStart, _ := providers.called("spc")
+	assert.Less(t, hedgeStart, 500*time.Millisecond)
+}
+
+func TestExecuteHedgedAllProvidersFail(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa":   {err: errors.New("timeout no bureau")},
+		"spc":      {latency: 20 * time.Millisecond, err: errors.New("credenciais inválidas")},
+		"boavista": {err: errors.New("não deve ser consultado")},
+	})
+
+	result, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{
+		Enabled:     true,
+		Delay:       10 * time.Millisecond,
+		MaxAttempts: 2,
+	}, nil, hedgeProviders, providers.call)
+	providers.wait(t, 2)
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "serasa: timeout no bureau")
+	assert.Contains(t, err.Error(), "spc: credenciais inválidas")
+	assert.NotContains(t, err.Error(), "boavista")
+}
+
+func TestExecuteHedgedContextCancellation(t *testing.T) {
+	providers := newFakeProviders(map[string]providerBehavior{
+		"serasa": {latency: time.Second},
+		"spc":    {latency: time.Second},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := orchestration.ExecuteHedged(ctx, orchestration.HedgingConfig{
+		Enabled: true,
+		Delay:   20 * time.Millisecond,
+	}, nil, hedgeProviders, providers.call)
+	providers.wait(t, 2)
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "a consulta termina com o contexto")
+	assert.True(t, providers.cancelled("serasa"))
+	assert.True(t, providers.cancelled("spc"))
+}
+
+func TestExecuteHedgedWithoutProviders(t *testing.T) {
+	_, err := orchestration.ExecuteHedged(context.Background(), orchestration.HedgingConfig{Enabled: true}, nil, nil,
+		func(ctx context.Context, provider string) (*adapters.CreditReportResponse, error) {
+			t.Fatal("nenhum provedor deve ser consultado")
+			return nil, nil
+		})
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+/**
+ * @file field_encryption.go
+ * @description Criptografia de campos de PII por mercado para o Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EncryptionMode define o modo de criptografia de um campo
+type EncryptionMode string
+
+const (
+	// ModeRandomized usa nonce aleatório; o mesmo valor gera textos cifrados diferentes
+	ModeRandomized EncryptionMode = "r"
+
+	// ModeDeterministic deriva o nonce do valor; permite pesquisa por igualdade
+	ModeDeterministic EncryptionMode = "d"
+)
+
+const (
+	// Prefixo e versão do envelope de campos cifrados
+	envelopePrefix  = "pii"
+	envelopeVersion = "v1"
+
+	// Tamanho exigido das chaves de mercado (AES-256)
+	keySize = 32
+
+	// Tamanho do nonce AES-GCM
+	nonceSize = 12
+
+	// Prefixo das variáveis de ambiente com chaves por mercado
+	envKeyPrefix = "BUREAU_PII_KEY_"
+)
+
+// Campos de PII do Bureau de Crédito
+const (
+	FieldDocumentoCliente = "documentoCliente"
+	FieldNomeCliente      = "nomeCliente"
+)
+
+// Chaves de metadados que registram a chave usada na criptografia
+const (
+	MetadataKeyID = "pii_key_id"
+	MetadataMode  = "pii_mode"
+)
+
+// Erros de criptografia de campos
+var (
+	ErrNoActiveKey       = errors.New("nenhuma chave ativa para o mercado")
+	ErrKeyNotFound       = errors.New("chave de criptografia não encontrada")
+	ErrInvalidKey        = errors.New("chave de criptografia inválida")
+	ErrInvalidEnvelope   = errors.New("formato de campo cifrado inválido")
+	ErrDecryptionFailure = errors.New("falha ao decifrar campo")
+)
+
+// marketKey representa uma chave de criptografia de um mercado
+type marketKey struct {
+	id            string
+	market        string
+	encKey        []byte
+	deterministic []byte
+}
+
+// KeyRing mantém as chaves de criptografia por mercado, permitindo rotação
+type KeyRing struct {
+	keys   map[string]*marketKey
+	active map[string]string
+	mutex  sync.RWMutex
+}
+
+// NewKeyRing cria um novo chaveiro vazio
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys:   make(map[string]*marketKey),
+		active: make(map[string]string),
+	}
+}
+
+// AddKey adiciona uma chave de 32 bytes para o mercado; active a torna a chave de cifragem
+func (k *KeyRing) AddKey(market, keyID string, key []byte, active bool) error {
+	if len(key) != keySize {
+		return fmt.Errorf("%w: esperado %d bytes, recebido %d", ErrInvalidKey, keySize, len(key))
+	}
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return fmt.Errorf("%w: identificador '%s'", ErrInvalidKey, keyID)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	// Derivar subchaves independentes para cifragem e derivação de nonce determinístico
+	k.keys[keyID] = &marketKey{
+		id:            keyID,
+		market:        market,
+		encKey:        deriveKey(key, "pii-encryption"),
+		deterministic: deriveKey(key, "pii-deterministic-nonce"),
+	}
+	if active {
+		k.active[market] = keyID
+	}
+
+	return nil
+}
+
+// ActiveKeyID retorna o identificador da chave ativa do mercado
+func (k *KeyRing) ActiveKeyID(market string) (string, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	keyID, ok := k.active[market]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoActiveKey, market)
+	}
+	return keyID, nil
+}
+
+// key retorna uma chave pelo identificador
+func (k *KeyRing) key(keyID string) (*marketKey, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	return key, nil
+}
+
+// LoadKeyRingFromEnv carrega chaves de BUREAU_PII_KEY_<MERCADO>=<keyID>:<chave base64>
+// Várias chaves podem ser separadas por vírgula; a primeira é a chave ativa
+func LoadKeyRingFromEnv(markets []string) (*KeyRing, error) {
+	ring := NewKeyRing()
+
+	for _, market := range markets {
+		value := os.Getenv(envKeyPrefix + strings.ToUpper(market))
+		if value == "" {
+			continue
+		}
+
+		for i, entry := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("%w: entrada inválida para o mercado %s", ErrInvalidKey, market)
+			}
+
+			key, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: chave %s não está em base64", ErrInvalidKey, parts[0])
+			}
+
+			if err := ring.AddKey(market, parts[0], key, i == 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ring, nil
+}
+
+// FieldEncryptor cifra e decifra campos de PII com AES-256-GCM por mercado
+type FieldEncryptor struct {
+	keyRing             *KeyRing
+	deterministicFields map[string]bool
+}
+
+// NewFieldEncryptor cria um cifrador; os campos indicados usam criptografia determinística
+func NewFieldEncryptor(keyRing *KeyRing, deterministicFields ...string) *FieldEncryptor {
+	fields := make(map[string]bool)
+	for _, f := range deterministicFields {
+		fields[f] = true
+	}
+	return &FieldEncryptor{
+		keyRing:             keyRing,
+		deterministicFields: fields,
+	}
+}
+
+// Encrypt cifra o valor do campo com a chave ativa do mercado
+// O resultado tem o formato pii:v1:<modo>:<keyID>:<nonce+cifrado em base64>
+func (e *FieldEncryptor) Encrypt(market, field, plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	keyID, err := e.keyRing.ActiveKeyID(market)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := e.keyRing.key(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	mode := ModeRandomized
+	if e.deterministicFields[field] {
+		mode = ModeDeterministic
+	}
+
+	return seal(key, mode, market, field, plaintext)
+}
+
+// Decrypt decifra um valor produzido por Encrypt; valores não cifrados são retornados sem alteração
+func (e *FieldEncryptor) Decrypt(market, field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(value, ":", 5)
+	if len(parts) != 5 || parts[1] != envelopeVersion {
+		return "", ErrInvalidEnvelope
+	}
+
+	key, err := e.keyRing.key(parts[3])
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[4])
+	if err != nil || len(data) < nonceSize {
+		return "", ErrInvalidEnvelope
+	}
+
+	aead, err := newAEAD(key.encKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], additionalData(market, field))
+	if err != nil {
+		return "", ErrDecryptionFailure
+	}
+
+	return string(plaintext), nil
+}
+
+// SearchToken retorna o valor cifrado determinístico usado em pesquisas por igualdade
+func (e *FieldEncryptor) SearchToken(market, field, plaintext string) (string, error) {
+	if !e.deterministicFields[field] {
+		return "", fmt.Errorf("campo %s não usa criptografia determinística", field)
+	}
+	return e.Encrypt(market, field, plaintext)
+}
+
+// IsEncrypted verifica se o valor está no formato de campo cifrado
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix+":"+envelopeVersion+":")
+}
+
+// KeyIDOf retorna o identificador da chave usada para cifrar o valor
+func KeyIDOf(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	parts := strings.SplitN(value, ":", 5)
+	if len(parts) != 5 {
+		return ""
+	}
+	return parts[3]
+}
+
+// seal cifra o valor e monta o envelope
+func seal(key *marketKey, mode EncryptionMode, market, field, plaintext string) (string, error) {
+	aead, err := newAEAD(key.encKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if mode == ModeDeterministic {
+		// Nonce sintético: HMAC do contexto e do valor, estável para o mesmo par chave/valor
+		mac := hmac.New(sha256.New, key.deterministic)
+		mac.Write(additionalData(market, field))
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("falha ao gerar nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), additionalData(market, field))
+
+	return strings.Join([]string{
+		envelopePrefix,
+		envelopeVersion,
+		string(mode),
+		key.id,
+		base64.RawURLEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// newAEAD cria a instância AES-GCM para a chave
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData vincula o texto cifrado ao mercado e ao campo
+func additionalData(market, field string) []byte {
+	return []byte(market + "|" + field)
+}
+
+// deriveKey deriva uma subchave da chave mestra do mercado
+func deriveKey(master []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,95 @@
+/**
+ * @file field_encryption_test.go
+ * @description Testes para a criptografia de campos de PII por mercado
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/pii"
+)
+
+// newTestEncryptor cria um cifrador com chaves para Angola e Brasil
+func newTestEncryptor(t *testing.T) (*pii.KeyRing, *pii.FieldEncryptor) {
+	ring := pii.NewKeyRing()
+	require.NoError(t, ring.AddKey("angola", "ao-2025-01", bytes.Repeat([]byte{1}, 32), true))
+	require.NoError(t, ring.AddKey("brazil", "br-2025-01", bytes.Repeat([]byte{2}, 32), true))
+	return ring, pii.NewFieldEncryptor(ring, pii.FieldDocumentoCliente)
+}
+
+// TestFieldEncryption_RoundTrip testa cifragem e decifragem nos dois modos
+func TestFieldEncryption_RoundTrip(t *testing.T) {
+	_, enc := newTestEncryptor(t)
+
+	documento, err := enc.Encrypt("angola", pii.FieldDocumentoCliente, "004567891LA042")
+	require.NoError(t, err)
+	assert.True(t, pii.IsEncrypted(documento))
+	assert.Equal(t, "ao-2025-01", pii.KeyIDOf(documento))
+	assert.NotContains(t, documento, "004567891LA042")
+
+	nome, err := enc.Encrypt("angola", pii.FieldNomeCliente, "Maria Silva")
+	require.NoError(t, err)
+
+	plain, err := enc.Decrypt("angola", pii.FieldDocumentoCliente, documento)
+	require.NoError(t, err)
+	assert.Equal(t, "004567891LA042", plain)
+
+	plain, err = enc.Decrypt("angola", pii.FieldNomeCliente, nome)
+	require.NoError(t, err)
+	assert.Equal(t, "Maria Silva", plain)
+}
+
+// TestFieldEncryption_Deterministic testa a estabilidade dos campos pesquisáveis
+func TestFieldEncryption_Deterministic(t *testing.T) {
+	_, enc := newTestEncryptor(t)
+
+	first, err := enc.Encrypt("brazil", pii.FieldDocumentoCliente, "123.456.789-09")
+	require.NoError(t, err)
+	second, err := enc.Encrypt("brazil", pii.FieldDocumentoCliente, "123.456.789-09")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	token, err := enc.SearchToken("brazil", pii.FieldDocumentoCliente, "123.456.789-09")
+	require.NoError(t, err)
+	assert.Equal(t, first, token)
+
+	// Campos não determinísticos geram valores diferentes a cada cifragem
+	a, _ := enc.Encrypt("brazil", pii.FieldNomeCliente, "João Souza")
+	b, _ := enc.Encrypt("brazil", pii.FieldNomeCliente, "João Souza")
+	assert.NotEqual(t, a, b)
+
+	_, err = enc.SearchToken("brazil", pii.FieldNomeCliente, "João Souza")
+	assert.Error(t, err)
+}
+
+// TestFieldEncryption_MarketIsolation testa que o texto cifrado fica vinculado ao mercado e à chave
+func TestFieldEncryption_MarketIsolation(t *testing.T) {
+	ring, enc := newTestEncryptor(t)
+
+	documento, err := enc.Encrypt("angola", pii.FieldDocumentoCliente, "004567891LA042")
+	require.NoError(t, err)
+
+	_, err = enc.Decrypt("brazil", pii.FieldDocumentoCliente, documento)
+	assert.ErrorIs(t, err, pii.ErrDecryptionFailure)
+
+	_, err = enc.Encrypt("eu", pii.FieldDocumentoCliente, "X123")
+	assert.ErrorIs(t, err, pii.ErrNoActiveKey)
+
+	// Após rotação, valores antigos continuam decifráveis
+	require.NoError(t, ring.AddKey("angola", "ao-2025-02", bytes.Repeat([]byte{3}, 32), true))
+	rotated, err := enc.Encrypt("angola", pii.FieldDocumentoCliente, "004567891LA042")
+	require.NoError(t, err)
+	assert.Equal(t, "ao-2025-02", pii.KeyIDOf(rotated))
+
+	plain, err := enc.Decrypt("angola", pii.FieldDocumentoCliente, documento)
+	require.NoError(t, err)
+	assert.Equal(t, "004567891LA042", plain)
+}
@@ -0,0 +1,586 @@
+/**
+ * @file connectors.go
+ * @description Conectores de registos públicos: API de cartórios de protesto e ficheiros de dívida ativa
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package registries
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader é o cabeçalho com a chave de acesso à API do registo
+const APIKeyHeader = "X-Api-Key"
+
+// DefaultMarket é o mercado dos registos quando o conector não o define
+const DefaultMarket = "brazil"
+
+// Connector lê uma fonte de registos públicos de forma incremental
+//
+// Fetch retorna o lote seguinte ao cursor (vazio na primeira sincronização); o cursor do lote
+// só é gravado no checkpoint depois de o lote ser ingerido
+type Connector interface {
+	Name() string
+	Fonte() Fonte
+	Fetch(ctx context.Context, cursor string) (*Lote, error)
+}
+
+// ProtestoAPIConfig define o acesso à API de consulta de protestos da central de cartórios
+type ProtestoAPIConfig struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	APIKey   string `json:"-"`
+	Market   string `json:"market"`
+	PageSize int    `json:"pageSize"`
+}
+
+// protestoPage é a página devolvida pela API de protestos
+type protestoPage struct {
+	Titulos       []protestoTitulo `json:"titulos"`
+	ProximoCursor string           `json:"proximoCursor"`
+	TemMais       bool             `json:"temMais"`
+}
+
+// protestoTitulo é um título protestado devolvido pela API
+type protestoTitulo struct {
+	Protocolo      string  `json:"protocolo"`
+	Documento      string  `json:"documentoDevedor"`
+	Valor          float64 `json:"valor"`
+	DataProtesto   string  `json:"dataProtesto"`
+	Situacao       string  `json:"situacao"`
+	CartorioCodigo string  `json:"cartorioCodigo"`
+	CartorioNome   string  `json:"cartorioNome"`
+	UF             string  `json:"uf"`
+	Especie        string  `json:"especie"`
+}
+
+// ProtestoAPIConnector lê os protestos alterados desde o cursor, por HTTP GET paginado
+// (?desde=<cursor>&limite=<pageSize>), e espera {"titulos": [...], "proximoCursor": "...", "temMais": true}
+//
+// Os cancelamentos e sustações chegam como alterações do mesmo protocolo e baixam o registo
+type ProtestoAPIConnector struct {
+	client *http.Client
+	config ProtestoAPIConfig
+}
+
+// NewProtestoAPIConnector cria o conector; client pode ser nil
+func NewProtestoAPIConnector(client *http.Client, config ProtestoAPIConfig) *ProtestoAPIConnector {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if config.Name == "" {
+		config.Name = "protesto-api"
+	}
+	if config.Market == "" {
+		config.Market = DefaultMarket
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = DefaultPageSize
+	}
+	return &ProtestoAPIConnector{client: client, config: config}
+}
+
+// Name identifica o conector
+func (c *ProtestoAPIConnector) Name() string {
+	return c.config.Name
+}
+
+// Fonte é o registo público lido pelo conector
+func (c *ProtestoAPIConnector) Fonte() Fonte {
+	return FonteProtesto
+}
+
+// Fetch lê a página de protestos seguinte ao cursor
+func (c *ProtestoAPIConnector) Fetch(ctx context.Context, cursor string) (*Lote, error) {
+	endpoint, err := url.Parse(c.config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint inválido do conector %s: %w", c.config.Name, err)
+	}
+	query := endpoint.Query()
+	if cursor != "" {
+		query.Set("desde", cursor)
+	}
+	query.Set("limite", strconv.Itoa(c.config.PageSize))
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set(APIKeyHeader, c.config.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registo %s indisponível: %w", c.config.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("registo %s respondeu %d", c.config.Name, resp.StatusCode)
+	}
+
+	var page protestoPage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&page); err != nil {
+		return nil, fmt.Errorf("resposta inválida do registo %s: %w", c.config.Name, err)
+	}
+	if page.TemMais && (page.ProximoCursor == "" || page.ProximoCursor == cursor) {
+		return nil, fmt.Errorf("%w: registo %s não avançou o cursor", ErrInvalidCursor, c.config.Name)
+	}
+
+	lote := &Lote{Cursor: page.ProximoCursor, Disponivel: page.TemMais}
+	if lote.Cursor == "" {
+		lote.Cursor = cursor
+	}
+	for i, titulo := range page.Titulos {
+		registro, err := c.normalize(titulo)
+		if err != nil {
+			lote.Rejeicoes = append(lote.Rejeicoes, Rejeicao{Posicao: strconv.Itoa(i), Motivo: err.Error()})
+			continue
+		}
+		lote.Registros = append(lote.Registros, registro)
+	}
+	return lote, nil
+}
+
+// normalize converte o título protestado em registo
+func (c *ProtestoAPIConnector) normalize(titulo protestoTitulo) (Registro, error) {
+	protocolo := strings.TrimSpace(titulo.Protocolo)
+	if protocolo == "" {
+		return Registro{}, errors.New("protocolo ausente")
+	}
+	documento, tipoEntidade, err := parseDocumento(titulo.Documento)
+	if err != nil {
+		return Registro{}, err
+	}
+	if titulo.Valor <= 0 {
+		return Registro{}, errors.New("valor inválido")
+	}
+	data, err := parseData(titulo.DataProtesto)
+	if err != nil {
+		return Registro{}, err
+	}
+
+	cartorio := strings.TrimSpace(titulo.CartorioCodigo)
+	registro := Registro{
+		ID:             registroID(FonteProtesto, cartorio, protocolo),
+		Fonte:          FonteProtesto,
+		Conector:       c.config.Name,
+		Market:         c.config.Market,
+		Documento:      documento,
+		TipoEntidade:   tipoEntidade,
+		Valor:          titulo.Valor,
+		DataOcorrencia: data,
+		Situacao:       situacaoProtesto(titulo.Situacao),
+		FonteID:        cartorio,
+		FonteNome:      strings.TrimSpace(titulo.CartorioNome),
+		Referencia:     protocolo,
+		Detalhes:       map[string]string{},
+	}
+	setDetalhe(registro.Detalhes, "uf", titulo.UF)
+	setDetalhe(registro.Detalhes, "especie", titulo.Especie)
+	setDetalhe(registro.Detalhes, "situacaoOrigem", titulo.Situacao)
+	return registro, nil
+}
+
+// situacaoProtesto baixa os protestos cancelados, sustados ou pagos em cartório
+func situacaoProtesto(situacao string) Situacao {
+	situacao = strings.ToUpper(situacao)
+	for _, baixa := range []string{"CANCEL", "SUST", "PAGO", "QUITAD"} {
+		if strings.Contains(situacao, baixa) {
+			return SituacaoBaixada
+		}
+	}
+	return SituacaoAtiva
+}
+
+// DividaAtivaFileConfig define a leitura dos ficheiros de dívida ativa
+type DividaAtivaFileConfig struct {
+	Name     string `json:"name"`
+	Market   string `json:"market"`
+	Pattern  string `json:"pattern"` // Padrão dos ficheiros no diretório (fs.Glob), por omissão "*.csv"
+	PageSize int    `json:"pageSize"`
+}
+
+// Colunas dos ficheiros de dívida ativa (formato dos dados abertos da PGFN)
+const (
+	colunaDocumento  = "CPF_CNPJ"
+	colunaTipoPessoa = "TIPO_PESSOA"
+	colunaInscricao  = "NUMERO_INSCRICAO"
+	colunaSituacao   = "SITUACAO_INSCRICAO"
+	colunaData       = "DATA_INSCRICAO"
+	colunaValor      = "VALOR_CONSOLIDADO"
+	colunaUnidade    = "UNIDADE_RESPONSAVEL"
+	colunaReceita    = "RECEITA_PRINCIPAL"
+	colunaAjuizado   = "INDICADOR_AJUIZADO"
+	colunaUF         = "UF_DEVEDOR"
+)
+
+const (
+	separadorColunas   = ';'
+	separadorCursor    = "#"
+	cursorFimArquivo   = "fim"
+	orgaoDividaAtiva   = "PGFN"
+	nomeOrgaoInscritor = "Procuradoria-Geral da Fazenda Nacional"
+)
+
+// DividaAtivaFileConnector lê os ficheiros CSV de dívida ativa de um diretório
+//
+// Os ficheiros são lidos por ordem de nome e tratados como imutáveis depois de publicados: cada nova
+// extração chega num ficheiro novo (ex.: com a data no nome). O cursor "<ficheiro>#<linhas lidas>"
+// retoma a leitura a meio de um ficheiro e "<ficheiro>#fim" marca-o como concluído
+type DividaAtivaFileConnector struct {
+	files  fs.FS
+	config DividaAtivaFileConfig
+
+	// aberto mantém o ficheiro em leitura entre lotes para não reler as linhas já processadas
+	aberto *arquivoAberto
+	mutex  sync.Mutex
+}
+
+// arquivoAberto é o ficheiro em leitura e a posição correspondente
+type arquivoAberto struct {
+	nome    string
+	linha   int
+	file    fs.File
+	reader  *csv.Reader
+	colunas map[string]int
+}
+
+// NewDividaAtivaFileConnector cria o conector sobre o sistema de ficheiros (ex.: os.DirFS)
+func NewDividaAtivaFileConnector(files fs.FS, config DividaAtivaFileConfig) *DividaAtivaFileConnector {
+	if config.Name == "" {
+		config.Name = "divida-ativa-arquivos"
+	}
+	if config.Market == "" {
+		config.Market = DefaultMarket
+	}
+	if config.Pattern == "" {
+		config.Pattern = "*.csv"
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = DefaultPageSize
+	}
+	return &DividaAtivaFileConnector{files: files, config: config}
+}
+
+// Name identifica o conector
+func (c *DividaAtivaFileConnector) Name() string {
+	return c.config.Name
+}
+
+// Fonte é o registo público lido pelo conector
+func (c *DividaAtivaFileConnector) Fonte() Fonte {
+	return FonteDividaAtiva
+}
+
+// Fetch lê até PageSize linhas a partir do cursor, passando aos ficheiros seguintes quando necessário
+func (c *DividaAtivaFileConnector) Fetch(ctx context.Context, cursor string) (*Lote, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	arquivoAtual, linha, concluido, err := parseFileCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	nomes, err := fs.Glob(c.files, c.config.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("padrão inválido do conector %s: %w", c.config.Name, err)
+	}
+	sort.Strings(nomes)
+
+	lote := &Lote{Cursor: cursor}
+	for _, nome := range nomes {
+		if nome < arquivoAtual || (nome == arquivoAtual && concluido) {
+			continue
+		}
+		inicio := 0
+		if nome == arquivoAtual {
+			inicio = linha
+		}
+
+		if len(lote.Registros)+len(lote.Rejeicoes) >= c.config.PageSize {
+			lote.Disponivel = true
+			return lote, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		aberto, err := c.abrir(nome, inicio)
+		if err != nil {
+			return nil, err
+		}
+		fim, err := c.lerLinhas(aberto, lote)
+		if err != nil {
+			c.fechar()
+			return nil, err
+		}
+		if !fim {
+			lote.Cursor = aberto.nome + separadorCursor + strconv.Itoa(aberto.linha)
+			lote.Disponivel = true
+			return lote, nil
+		}
+		c.fechar()
+		lote.Cursor = nome + separadorCursor + cursorFimArquivo
+	}
+	return lote, nil
+}
+
+// abrir retoma o ficheiro em leitura ou abre-o e salta as linhas já ingeridas
+func (c *DividaAtivaFileConnector) abrir(nome string, linha int) (*arquivoAberto, error) {
+	if c.aberto != nil && c.aberto.nome == nome && c.aberto.linha == linha {
+		return c.aberto, nil
+	}
+	c.fechar()
+
+	file, err := c.files.Open(nome)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir %s: %w", nome, err)
+	}
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.Comma = separadorColunas
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		file.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("ficheiro %s sem cabeçalho", nome)
+		}
+		return nil, fmt.Errorf("erro ao ler o cabeçalho de %s: %w", nome, err)
+	}
+	colunas := make(map[string]int, len(header))
+	for i, coluna := range header {
+		coluna = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(coluna, "\ufeff")))
+		colunas[coluna] = i
+	}
+	for _, obrigatoria := range []string{colunaDocumento, colunaInscricao, colunaData, colunaValor} {
+		if _, ok := colunas[obrigatoria]; !ok {
+			file.Close()
+			return nil, fmt.Errorf("ficheiro %s sem a coluna %s", nome, obrigatoria)
+		}
+	}
+
+	aberto := &arquivoAberto{nome: nome, file: file, reader: reader, colunas: colunas}
+	for aberto.linha < linha {
+		if _, err := reader.Read(); err != nil {
+			file.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: %s tem menos de %d linhas", ErrInvalidCursor, nome, linha)
+			}
+			return nil, fmt.Errorf("erro ao retomar %s: %w", nome, err)
+		}
+		aberto.linha++
+	}
+	c.aberto = aberto
+	return aberto, nil
+}
+
+// lerLinhas acrescenta ao lote as linhas do ficheiro até encher a página; retorna true no fim do ficheiro
+func (c *DividaAtivaFileConnector) lerLinhas(aberto *arquivoAberto, lote *Lote) (bool, error) {
+	for len(lote.Registros)+len(lote.Rejeicoes) < c.config.PageSize {
+		record, err := aberto.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		aberto.linha++
+		posicao := aberto.nome + separadorCursor + strconv.Itoa(aberto.linha)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				lote.Rejeicoes = append(lote.Rejeicoes, Rejeicao{Posicao: posicao, Motivo: "linha mal formada"})
+				continue
+			}
+			return false, fmt.Errorf("erro ao ler %s: %w", aberto.nome, err)
+		}
+
+		registro, err := c.normalize(aberto.colunas, record)
+		if err != nil {
+			lote.Rejeicoes = append(lote.Rejeicoes, Rejeicao{Posicao: posicao, Motivo: err.Error()})
+			continue
+		}
+		setDetalhe(registro.Detalhes, "arquivo", aberto.nome)
+		lote.Registros = append(lote.Registros, registro)
+	}
+	return false, nil
+}
+
+// fechar liberta o ficheiro em leitura
+func (c *DividaAtivaFileConnector) fechar() {
+	if c.aberto != nil {
+		c.aberto.file.Close()
+		c.aberto = nil
+	}
+}
+
+// normalize converte a linha da inscrição em registo
+func (c *DividaAtivaFileConnector) normalize(colunas map[string]int, record []string) (Registro, error) {
+	campo := func(coluna string) string {
+		if i, ok := colunas[coluna]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	inscricao := campo(colunaInscricao)
+	if inscricao == "" {
+		return Registro{}, errors.New("inscrição ausente")
+	}
+	documento, tipoEntidade, err := parseDocumento(campo(colunaDocumento))
+	if err != nil {
+		return Registro{}, err
+	}
+	valor, err := parseValor(campo(colunaValor))
+	if err != nil {
+		return Registro{}, err
+	}
+	data, err := parseData(campo(colunaData))
+	if err != nil {
+		return Registro{}, err
+	}
+
+	registro := Registro{
+		ID:             registroID(FonteDividaAtiva, orgaoDividaAtiva, inscricao),
+		Fonte:          FonteDividaAtiva,
+		Conector:       c.config.Name,
+		Market:         c.config.Market,
+		Documento:      documento,
+		TipoEntidade:   tipoEntidade,
+		Valor:          valor,
+		DataOcorrencia: data,
+		Situacao:       situacaoDividaAtiva(campo(colunaSituacao)),
+		FonteID:        orgaoDividaAtiva,
+		FonteNome:      nomeOrgaoInscritor,
+		Referencia:     inscricao,
+		Detalhes:       map[string]string{},
+	}
+	setDetalhe(registro.Detalhes, "tipoPessoa", campo(colunaTipoPessoa))
+	setDetalhe(registro.Detalhes, "situacaoOrigem", campo(colunaSituacao))
+	setDetalhe(registro.Detalhes, "unidadeResponsavel", campo(colunaUnidade))
+	setDetalhe(registro.Detalhes, "receitaPrincipal", campo(colunaReceita))
+	setDetalhe(registro.Detalhes, "ajuizado", campo(colunaAjuizado))
+	setDetalhe(registro.Detalhes, "uf", campo(colunaUF))
+	return registro, nil
+}
+
+// situacaoDividaAtiva baixa as inscrições extintas, pagas ou canceladas
+// As inscrições suspensas ou com parcelamento continuam ativas
+func situacaoDividaAtiva(situacao string) Situacao {
+	situacao = strings.ToUpper(situacao)
+	for _, baixa := range []string{"EXTIN", "PAGA", "QUITAD", "CANCEL"} {
+		if strings.Contains(situacao, baixa) {
+			return SituacaoBaixada
+		}
+	}
+	return SituacaoAtiva
+}
+
+// parseFileCursor separa o cursor em ficheiro, linhas lidas e indicação de ficheiro concluído
+func parseFileCursor(cursor string) (string, int, bool, error) {
+	if cursor == "" {
+		return "", 0, false, nil
+	}
+	i := strings.LastIndex(cursor, separadorCursor)
+	if i <= 0 {
+		return "", 0, false, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	nome, posicao := cursor[:i], cursor[i+1:]
+	if posicao == cursorFimArquivo {
+		return nome, 0, true, nil
+	}
+	linha, err := strconv.Atoi(posicao)
+	if err != nil || linha < 0 {
+		return "", 0, false, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return nome, linha, false, nil
+}
+
+// registroID deriva o ID estável do registo a partir da fonte, do emissor e da referência
+func registroID(fonte Fonte, emissor, referencia string) string {
+	sum := sha256.Sum256([]byte(string(fonte) + "|" + emissor + "|" + referencia))
+	return hex.EncodeToString(sum[:12])
+}
+
+// normalizeDocumento mantém apenas os dígitos do CPF ou CNPJ
+func normalizeDocumento(documento string) string {
+	var b strings.Builder
+	for _, r := range documento {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parseDocumento normaliza o documento e deduz o tipo de entidade pelo número de dígitos
+func parseDocumento(documento string) (string, string, error) {
+	if strings.ContainsAny(documento, "Xx*") {
+		return "", "", errors.New("documento mascarado")
+	}
+	documento = normalizeDocumento(documento)
+	switch len(documento) {
+	case 11:
+		return documento, "PF", nil
+	case 14:
+		return documento, "PJ", nil
+	default:
+		return "", "", errors.New("documento inválido")
+	}
+}
+
+// parseValor aceita valores no formato brasileiro (1.234,56) ou com ponto decimal (1234.56)
+func parseValor(valor string) (float64, error) {
+	valor = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(valor), "R$"))
+	if strings.Contains(valor, ",") {
+		valor = strings.ReplaceAll(valor, ".", "")
+		valor = strings.Replace(valor, ",", ".", 1)
+	}
+	parsed, err := strconv.ParseFloat(valor, 64)
+	if err != nil || parsed <= 0 {
+		return 0, errors.New("valor inválido")
+	}
+	return parsed, nil
+}
+
+// parseData aceita datas dd/mm/aaaa, aaaa-mm-dd ou RFC 3339
+func parseData(data string) (time.Time, error) {
+	data = strings.TrimSpace(data)
+	for _, layout := range []string{"02/01/2006", "2006-01-02", time.RFC3339} {
+		if parsed, err := time.Parse(layout, data); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New("data inválida")
+}
+
+// setDetalhe grava o detalhe quando tem valor
+func setDetalhe(detalhes map[string]string, chave, valor string) {
+	if valor = strings.TrimSpace(valor); valor != "" {
+		detalhes[chave] = valor
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * @file handler.go
+ * @description API HTTP de operação da ingestão de registos públicos do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package registries
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Handler expõe a API de operação dos conectores de registos públicos:
+//
+//	GET  /registries/connectors                        lista os conectores com o agendamento e o checkpoint
+//	POST /registries/connectors/{nome}/sync            sincroniza o conector imediatamente
+//	GET  /registries/connectors/{nome}/runs?limit=N    lista as sincronizações, mais recentes primeiro
+//	GET  /registries/alerts?connector=X&limit=N        lista os alertas de qualidade de dados
+type Handler struct {
+	ingestor *Ingestor
+}
+
+// NewHandler cria o handler da API dos registos públicos
+func NewHandler(ingestor *Ingestor) *Handler {
+	return &Handler{ingestor: ingestor}
+}
+
+// Register regista as rotas no multiplexador
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/registries/", h)
+}
+
+// ServeHTTP encaminha o pedido pelo caminho e pelo método
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/registries"), "/")
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, "/")
+	}
+	if !knownRoute(segments) {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && segments[0] == "connectors" && r.Method == http.MethodGet:
+		connectors, err := h.ingestor.Connectors(r.Context())
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, connectors)
+	case len(segments) == 1 && segments[0] == "alerts" && r.Method == http.MethodGet:
+		limit, ok := parseLimit(w, r)
+		if !ok {
+			return
+		}
+		alertas, err := h.ingestor.ListAlertas(r.Context(), r.URL.Query().Get("connector"), limit)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, alertas)
+	case len(segments) == 3 && segments[0] == "connectors" && segments[2] == "sync" && r.Method == http.MethodPost:
+		run, err := h.ingestor.Sync(r.Context(), segments[1])
+		if err != nil && run == nil {
+			h.writeError(w, err)
+			return
+		}
+		// A sincronização falhada é registada no histórico e retornada com o erro
+		status := http.StatusOK
+		if run.Status == SyncFailed {
+			status = http.StatusBadGateway
+		}
+		writeJSON(w, status, run)
+	case len(segments) == 3 && segments[0] == "connectors" && segments[2] == "runs" && r.Method == http.MethodGet:
+		limit, ok := parseLimit(w, r)
+		if !ok {
+			return
+		}
+		runs, err := h.ingestor.ListRuns(r.Context(), segments[1], limit)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, runs)
+	default:
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// knownRoute indica se o caminho corresponde a uma das rotas, independentemente do método
+func knownRoute(segments []string) bool {
+	switch len(segments) {
+	case 1:
+		return segments[0] == "connectors" || segments[0] == "alerts"
+	case 3:
+		return segments[0] == "connectors" && (segments[2] == "sync" || segments[2] == "runs")
+	default:
+		return false
+	}
+}
+
+// parseLimit lê o parâmetro limit; responde 400 quando é inválido
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	return limit, true
+}
+
+// writeError converte os erros da ingestão em respostas HTTP
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrConnectorNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSyncInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Error().Err(err).Msg("Erro na API de registos públicos")
+		http.Error(w, "erro interno", http.StatusInternalServerError)
+	}
+}
+
+// writeJSON serializa a resposta JSON
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error().Err(err).Msg("Erro ao serializar resposta da API de registos públicos")
+	}
+}
@@ -0,0 +1,604 @@
+/**
+ * @file ingestor.go
+ * @description Sincronização agendada dos registos públicos com checkpoints incrementais e alertas de qualidade de dados
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package registries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Valores padrão da ingestão
+const (
+	DefaultPollInterval    = time.Minute
+	DefaultSyncInterval    = 6 * time.Hour
+	DefaultPageSize        = 500
+	DefaultMaxLotesPorSync = 200
+	DefaultMaxHistory      = 100
+	DefaultRunsLimit       = 20
+
+	// maxRejeicoesPorSync limita as rejeições guardadas no registo da sincronização
+	maxRejeicoesPorSync = 50
+)
+
+// QualityConfig define a deteção de anomalias no volume das sincronizações
+//
+// O volume de uma sincronização concluída é comparado com a mediana das últimas sincronizações
+// concluídas do conector. As sincronizações parciais ou falhadas que a precedem contam para o
+// mesmo ciclo, pelo que uma fonte com atraso não gera alertas de volume baixo seguidos de alto
+type QualityConfig struct {
+	Historico         int     `json:"historico"`         // Sincronizações concluídas usadas na mediana
+	MinHistorico      int     `json:"minHistorico"`      // Mínimo de sincronizações antes de avaliar o volume
+	MinMediana        float64 `json:"minMediana"`        // Abaixo desta mediana o volume é demasiado irregular para avaliar
+	FatorAlto         float64 `json:"fatorAlto"`         // Volume acima de mediana × FatorAlto gera VOLUME_ALTO
+	FatorBaixo        float64 `json:"fatorBaixo"`        // Volume abaixo de mediana × FatorBaixo gera VOLUME_BAIXO
+	MinAmostra        int     `json:"minAmostra"`        // Registos lidos antes de avaliar a taxa de rejeição
+	MaxTaxaRejeicao   float64 `json:"maxTaxaRejeicao"`   // Fração de rejeitados acima da qual é gerado TAXA_REJEICAO
+	MaxFalhasSeguidas int     `json:"maxFalhasSeguidas"` // Falhas consecutivas que geram FALHAS_SEGUIDAS
+}
+
+// Config define a sincronização dos conectores
+type Config struct {
+	PollInterval    time.Duration `json:"pollInterval"`    // Frequência com que os conectores vencidos são procurados
+	MaxLotesPorSync int           `json:"maxLotesPorSync"` // Lotes por sincronização; o restante fica para o ciclo seguinte
+	Quality         QualityConfig `json:"quality"`
+}
+
+// DefaultConfig retorna a configuração padrão da ingestão
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:    DefaultPollInterval,
+		MaxLotesPorSync: DefaultMaxLotesPorSync,
+		Quality: QualityConfig{
+			Historico:         10,
+			MinHistorico:      3,
+			MinMediana:        20,
+			FatorAlto:         3,
+			FatorBaixo:        0.3,
+			MinAmostra:        20,
+			MaxTaxaRejeicao:   0.05,
+			MaxFalhasSeguidas: 3,
+		},
+	}
+}
+
+// ConnectorStatus é o estado de um conector registado
+type ConnectorStatus struct {
+	Nome        string     `json:"nome"`
+	Fonte       Fonte      `json:"fonte"`
+	Intervalo   string     `json:"intervalo"`
+	ProximaSync time.Time  `json:"proximaSync"`
+	EmCurso     bool       `json:"emCurso"`
+	Checkpoint  Checkpoint `json:"checkpoint"`
+}
+
+// connectorState é o agendamento de um conector
+type connectorState struct {
+	connector   Connector
+	intervalo   time.Duration
+	proximaSync time.Time
+	emCurso     bool
+}
+
+// Ingestor sincroniza os conectores na sua frequência, entrega os registos normalizados ao
+// Sink, grava o checkpoint depois de cada lote ingerido e avalia a qualidade de cada sincronização
+type Ingestor struct {
+	store    Store
+	sink     Sink
+	notifier AlertNotifier
+	config   Config
+	metrics  *Metrics
+	now      func() time.Time
+
+	// mutex protege o agendamento dos conectores
+	connectors map[string]*connectorState
+	mutex      sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewIngestor cria a ingestão; notifier pode ser nil quando os alertas apenas são registados
+func NewIngestor(store Store, sink Sink, notifier AlertNotifier, config Config) *Ingestor {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MaxLotesPorSync <= 0 {
+		config.MaxLotesPorSync = defaults.MaxLotesPorSync
+	}
+	if config.Quality.Historico <= 0 {
+		config.Quality = defaults.Quality
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Ingestor{
+		store:      store,
+		sink:       sink,
+		notifier:   notifier,
+		config:     config,
+		now:        time.Now,
+		connectors: make(map[string]*connectorState),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// SetClock substitui o relógio usado no agendamento das sincronizações
+func (i *Ingestor) SetClock(now func() time.Time) {
+	i.now = now
+}
+
+// SetMetrics define as métricas Prometheus da ingestão
+func (i *Ingestor) SetMetrics(metrics *Metrics) {
+	i.metrics = metrics
+}
+
+// Register regista o conector, sincronizado no ciclo seguinte e depois a cada intervalo;
+// um intervalo não positivo usa DefaultSyncInterval
+func (i *Ingestor) Register(connector Connector, intervalo time.Duration) error {
+	if intervalo <= 0 {
+		intervalo = DefaultSyncInterval
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if _, exists := i.connectors[connector.Name()]; exists {
+		return fmt.Errorf("conector %s já registado", connector.Name())
+	}
+	i.connectors[connector.Name()] = &connectorState{connector: connector, intervalo: intervalo}
+	return nil
+}
+
+// Connectors retorna o estado dos conectores registados, por nome
+func (i *Ingestor) Connectors(ctx context.Context) ([]ConnectorStatus, error) {
+	i.mutex.Lock()
+	result := make([]ConnectorStatus, 0, len(i.connectors))
+	for nome, state := range i.connectors {
+		result = append(result, ConnectorStatus{
+			Nome:        nome,
+			Fonte:       state.connector.Fonte(),
+			Intervalo:   state.intervalo.String(),
+			ProximaSync: state.proximaSync,
+			EmCurso:     state.emCurso,
+		})
+	}
+	i.mutex.Unlock()
+
+	sort.Slice(result, func(a, b int) bool { return result[a].Nome < result[b].Nome })
+	for idx := range result {
+		checkpoint, err := i.store.GetCheckpoint(ctx, result[idx].Nome)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter checkpoint de %s: %w", result[idx].Nome, err)
+		}
+		result[idx].Checkpoint = checkpoint
+	}
+	return result, nil
+}
+
+// Start inicia o worker que sincroniza os conectores vencidos
+func (i *Ingestor) Start() {
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		ticker := time.NewTicker(i.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-ticker.C:
+				i.RunOnce(i.ctx)
+			}
+		}
+	}()
+}
+
+// Stop interrompe o worker; uma sincronização interrompida é retomada a partir do último checkpoint
+func (i *Ingestor) Stop() {
+	i.cancel()
+	i.wg.Wait()
+}
+
+// RunOnce sincroniza os conectores vencidos e retorna quantos foram sincronizados
+// A falha de um conector é registada no seu histórico e não impede a sincronização dos restantes
+func (i *Ingestor) RunOnce(ctx context.Context) int {
+	now := i.now()
+
+	i.mutex.Lock()
+	var due []string
+	for nome, state := range i.connectors {
+		if !state.emCurso && !now.Before(state.proximaSync) {
+			due = append(due, nome)
+		}
+	}
+	i.mutex.Unlock()
+
+	sort.Strings(due)
+	synced := 0
+	for _, nome := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := i.sync(ctx, nome, false); err != nil {
+			if errors.Is(err, ErrSyncInProgress) {
+				continue
+			}
+			log.Error().Err(err).Str("conector", nome).Msg("Erro na sincronização de registo público do Bureau de Crédito")
+		}
+		synced++
+	}
+	return synced
+}
+
+// Sync sincroniza o conector imediatamente, sem alterar o seu agendamento se falhar
+// O registo da sincronização é retornado mesmo quando esta falha
+func (i *Ingestor) Sync(ctx context.Context, nome string) (*SyncRun, error) {
+	return i.sync(ctx, nome, true)
+}
+
+// ListRuns lista as sincronizações do conector, mais recentes primeiro
+func (i *Ingestor) ListRuns(ctx context.Context, nome string, limit int) ([]*SyncRun, error) {
+	if !i.registered(nome) {
+		return nil, fmt.Errorf("%w: %s", ErrConnectorNotFound, nome)
+	}
+	if limit <= 0 {
+		limit = DefaultRunsLimit
+	}
+	return i.store.ListRuns(ctx, nome, limit)
+}
+
+// ListAlertas lista os alertas de qualidade de dados, mais recentes primeiro; nome vazio lista os de todos
+func (i *Ingestor) ListAlertas(ctx context.Context, nome string, limit int) ([]Alerta, error) {
+	if nome != "" && !i.registered(nome) {
+		return nil, fmt.Errorf("%w: %s", ErrConnectorNotFound, nome)
+	}
+	if limit <= 0 {
+		limit = DefaultRunsLimit
+	}
+	return i.store.ListAlertas(ctx, nome, limit)
+}
+
+// registered indica se o conector está registado
+func (i *Ingestor) registered(nome string) bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	_, ok := i.connectors[nome]
+	return ok
+}
+
+// sync marca o conector em curso, lê os lotes desde o checkpoint e agenda a sincronização seguinte
+func (i *Ingestor) sync(ctx context.Context, nome string, manual bool) (*SyncRun, error) {
+	i.mutex.Lock()
+	state, ok := i.connectors[nome]
+	if !ok {
+		i.mutex.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrConnectorNotFound, nome)
+	}
+	if state.emCurso {
+		i.mutex.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrSyncInProgress, nome)
+	}
+	state.emCurso = true
+	i.mutex.Unlock()
+
+	run, err := i.execute(ctx, state.connector, manual)
+
+	i.mutex.Lock()
+	state.emCurso = false
+	if run != nil {
+		switch {
+		case run.Status == SyncPartial:
+			// O restante é lido no ciclo seguinte, sem esperar pelo intervalo
+			state.proximaSync = run.ConcluidaEm
+		case run.Status == SyncCompleted || !manual:
+			state.proximaSync = run.ConcluidaEm.Add(state.intervalo)
+		}
+	}
+	i.mutex.Unlock()
+	return run, err
+}
+
+// execute ingere os lotes do conector a partir do checkpoint
+//
+// O checkpoint só avança depois de o Sink aceitar o lote: uma falha a meio repete, na
+// sincronização seguinte, apenas o lote não confirmado, que o Sink grava de forma idempotente
+func (i *Ingestor) execute(ctx context.Context, connector Connector, manual bool) (*SyncRun, error) {
+	nome := connector.Name()
+	checkpoint, err := i.store.GetCheckpoint(ctx, nome)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter checkpoint de %s: %w", nome, err)
+	}
+
+	run := &SyncRun{
+		ID:            uuid.New().String(),
+		Conector:      nome,
+		Fonte:         connector.Fonte(),
+		Manual:        manual,
+		IniciadaEm:    i.now(),
+		CursorInicial: checkpoint.Cursor,
+		CursorFinal:   checkpoint.Cursor,
+		Status:        SyncPartial,
+	}
+
+	var syncErr error
+	for run.Lotes < i.config.MaxLotesPorSync {
+		lote, err := connector.Fetch(ctx, checkpoint.Cursor)
+		if err != nil {
+			syncErr = fmt.Errorf("erro ao ler lote de %s: %w", nome, err)
+			break
+		}
+
+		now := i.now()
+		for idx := range lote.Registros {
+			lote.Registros[idx].Conector = nome
+			lote.Registros[idx].AtualizadoEm = now
+		}
+		if len(lote.Registros) > 0 {
+			if err := i.sink.Ingerir(ctx, lote.Registros); err != nil {
+				syncErr = fmt.Errorf("erro ao ingerir lote de %s: %w", nome, err)
+				break
+			}
+		}
+
+		checkpoint.Cursor = lote.Cursor
+		checkpoint.AtualizadoEm = now
+		checkpoint.TotalIngerido += int64(len(lote.Registros))
+		if err := i.store.SaveCheckpoint(ctx, checkpoint); err != nil {
+			syncErr = fmt.Errorf("erro ao gravar checkpoint de %s: %w", nome, err)
+			break
+		}
+
+		run.Lotes++
+		run.CursorFinal = lote.Cursor
+		run.Ingeridos += len(lote.Registros)
+		run.Rejeitados += len(lote.Rejeicoes)
+		for _, registro := range lote.Registros {
+			if registro.Situacao == SituacaoBaixada {
+				run.Baixados++
+			}
+		}
+		for _, rejeicao := range lote.Rejeicoes {
+			if len(run.Rejeicoes) == maxRejeicoesPorSync {
+				break
+			}
+			run.Rejeicoes = append(run.Rejeicoes, rejeicao)
+		}
+		i.metrics.observeLote(nome, len(lote.Registros), len(lote.Rejeicoes))
+
+		if !lote.Disponivel {
+			run.Status = SyncCompleted
+			break
+		}
+	}
+	if syncErr != nil {
+		run.Status = SyncFailed
+		run.Erro = syncErr.Error()
+	}
+	run.ConcluidaEm = i.now()
+
+	i.finish(ctx, run)
+	return run, syncErr
+}
+
+// finish avalia a qualidade da sincronização, grava-a e regista os alertas
+func (i *Ingestor) finish(ctx context.Context, run *SyncRun) {
+	// O registo é gravado mesmo com um contexto cancelado, para não perder o histórico
+	ctx = context.WithoutCancel(ctx)
+
+	previous, err := i.store.ListRuns(ctx, run.Conector, i.config.Quality.Historico*4)
+	if err != nil {
+		log.Error().Err(err).Str("conector", run.Conector).Msg("Erro ao obter histórico de sincronizações do Bureau de Crédito")
+	}
+
+	run.Volume = run.lidos()
+	if len(previous) > 0 && previous[0].Status != SyncCompleted {
+		run.Volume += previous[0].Volume
+	}
+	run.Alertas = i.evaluate(run, previous)
+
+	if err := i.store.SaveRun(ctx, run); err != nil {
+		log.Error().Err(err).Str("conector", run.Conector).Msg("Erro ao gravar sincronização de registo público do Bureau de Crédito")
+	}
+	i.metrics.observeSync(run)
+
+	for _, alerta := range run.Alertas {
+		if err := i.store.SaveAlerta(ctx, alerta); err != nil {
+			log.Error().Err(err).Str("conector", run.Conector).Msg("Erro ao gravar alerta de qualidade de dados")
+		}
+		i.metrics.observeAlerta(alerta)
+		log.Warn().
+			Str("conector", alerta.Conector).
+			Str("tipo", string(alerta.Tipo)).
+			Float64("observado", alerta.Observado).
+			Float64("referencia", alerta.Referencia).
+			Msg(alerta.Mensagem)
+		if i.notifier != nil {
+			if err := i.notifier.NotificarAlerta(ctx, alerta); err != nil {
+				log.Error().Err(err).Str("conector", alerta.Conector).Msg("Erro ao notificar alerta de qualidade de dados")
+			}
+		}
+	}
+
+	log.Info().
+		Str("conector", run.Conector).
+		Str("status", string(run.Status)).
+		Int("lotes", run.Lotes).
+		Int("ingeridos", run.Ingeridos).
+		Int("rejeitados", run.Rejeitados).
+		Msg("Sincronização de registo público concluída")
+}
+
+// evaluate compara a sincronização com o histórico do conector e retorna os alertas de qualidade
+func (i *Ingestor) evaluate(run *SyncRun, previous []*SyncRun) []Alerta {
+	quality := i.config.Quality
+	var alertas []Alerta
+	alerta := func(tipo TipoAlerta, observado, referencia float64, mensagem string) {
+		alertas = append(alertas, Alerta{
+			ID:         uuid.New().String(),
+			Conector:   run.Conector,
+			Fonte:      run.Fonte,
+			SyncID:     run.ID,
+			Tipo:       tipo,
+			Mensagem:   mensagem,
+			Observado:  observado,
+			Referencia: referencia,
+			CriadoEm:   run.ConcluidaEm,
+		})
+	}
+
+	if run.Status == SyncFailed {
+		falhas := 1
+		for _, anterior := range previous {
+			if anterior.Status != SyncFailed {
+				break
+			}
+			falhas++
+		}
+		// Alerta apenas ao atingir o limite, para não repetir a cada tentativa
+		if falhas == quality.MaxFalhasSeguidas {
+			alerta(AlertaFalhasSeguidas, float64(falhas), float64(quality.MaxFalhasSeguidas),
+				fmt.Sprintf("%d sincronizações falhadas seguidas de %s", falhas, run.Conector))
+		}
+	}
+
+	if lidos := run.lidos(); lidos >= quality.MinAmostra && lidos > 0 {
+		taxa := float64(run.Rejeitados) / float64(lidos)
+		if taxa > quality.MaxTaxaRejeicao {
+			alerta(AlertaTaxaRejeicao, taxa, quality.MaxTaxaRejeicao,
+				fmt.Sprintf("%.1f%% dos registos de %s recusados na normalização", taxa*100, run.Conector))
+		}
+	}
+
+	if run.Status != SyncCompleted {
+		return alertas
+	}
+	var volumes []float64
+	for _, anterior := range previous {
+		if len(volumes) == quality.Historico {
+			break
+		}
+		if anterior.Status == SyncCompleted {
+			volumes = append(volumes, float64(anterior.Volume))
+		}
+	}
+	if len(volumes) < quality.MinHistorico {
+		return alertas
+	}
+	mediana := median(volumes)
+	if mediana < quality.MinMediana {
+		return alertas
+	}
+
+	volume := float64(run.Volume)
+	switch {
+	case volume == 0:
+		alerta(AlertaSemRegistos, volume, mediana,
+			fmt.Sprintf("Nenhum registo lido de %s (mediana %.0f)", run.Conector, mediana))
+	case volume > mediana*quality.FatorAlto:
+		alerta(AlertaVolumeAlto, volume, mediana,
+			fmt.Sprintf("Volume de %s muito acima do habitual: %.0f registos (mediana %.0f)", run.Conector, volume, mediana))
+	case volume < mediana*quality.FatorBaixo:
+		alerta(AlertaVolumeBaixo, volume, mediana,
+			fmt.Sprintf("Volume de %s muito abaixo do habitual: %.0f registos (mediana %.0f)", run.Conector, volume, mediana))
+	}
+	return alertas
+}
+
+// median calcula a mediana dos valores
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
+
+// Metrics contém as métricas Prometheus da ingestão
+type Metrics struct {
+	registrosCounter *prometheus.CounterVec
+	syncsCounter     *prometheus.CounterVec
+	alertasCounter   *prometheus.CounterVec
+	lastSuccessGauge *prometheus.GaugeVec
+}
+
+// NewMetrics cria e regista as métricas da ingestão
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registrosCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_registries_registros_total",
+				Help: "Número total de registos públicos lidos pelo resultado da normalização (ingested, rejected)",
+			},
+			[]string{"connector", "result"},
+		),
+		syncsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_registries_syncs_total",
+				Help: "Número total de sincronizações de registos públicos pelo resultado",
+			},
+			[]string{"connector", "status"},
+		),
+		alertasCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_registries_quality_alerts_total",
+				Help: "Número total de alertas de qualidade de dados dos registos públicos pelo tipo",
+			},
+			[]string{"connector", "type"},
+		),
+		lastSuccessGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_registries_last_success_timestamp_seconds",
+				Help: "Instante da última sincronização concluída de cada conector de registos públicos",
+			},
+			[]string{"connector"},
+		),
+	}
+
+	registry.MustRegister(m.registrosCounter, m.syncsCounter, m.alertasCounter, m.lastSuccessGauge)
+	return m
+}
+
+// observeLote regista os registos de um lote ingerido
+func (m *Metrics) observeLote(conector string, ingeridos, rejeitados int) {
+	if m == nil {
+		return
+	}
+	m.registrosCounter.WithLabelValues(conector, "ingested").Add(float64(ingeridos))
+	m.registrosCounter.WithLabelValues(conector, "rejected").Add(float64(rejeitados))
+}
+
+// observeSync regista o resultado de uma sincronização
+func (m *Metrics) observeSync(run *SyncRun) {
+	if m == nil {
+		return
+	}
+	m.syncsCounter.WithLabelValues(run.Conector, string(run.Status)).Inc()
+	if run.Status == SyncCompleted {
+		m.lastSuccessGauge.WithLabelValues(run.Conector).Set(float64(run.ConcluidaEm.Unix()))
+	}
+}
+
+// observeAlerta regista um alerta de qualidade de dados
+func (m *Metrics) observeAlerta(alerta Alerta) {
+	if m == nil {
+		return
+	}
+	m.alertasCounter.WithLabelValues(alerta.Conector, string(alerta.Tipo)).Inc()
+}
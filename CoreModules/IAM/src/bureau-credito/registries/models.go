@@ -0,0 +1,360 @@
+/**
+ * @file models.go
+ * @description Modelos da ingestão de registos públicos (protestos e dívida ativa) no Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package registries
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fonte identifica o registo público de origem
+type Fonte string
+
+const (
+	FonteProtesto    Fonte = "PROTESTO"     // Cartórios de protesto de títulos
+	FonteDividaAtiva Fonte = "DIVIDA_ATIVA" // Inscrições em dívida ativa
+)
+
+// Situacao indica se o registo continua a constituir uma restrição
+type Situacao string
+
+const (
+	SituacaoAtiva   Situacao = "ATIVA"
+	SituacaoBaixada Situacao = "BAIXADA" // Protesto cancelado ou sustado, inscrição extinta ou paga
+)
+
+// SyncStatus define o resultado de uma sincronização
+type SyncStatus string
+
+const (
+	SyncCompleted SyncStatus = "COMPLETED" // Todos os lotes disponíveis ingeridos
+	SyncPartial   SyncStatus = "PARTIAL"   // Limite de lotes atingido; continua na sincronização seguinte
+	SyncFailed    SyncStatus = "FAILED"    // Interrompida por erro; os lotes já ingeridos ficam no checkpoint
+)
+
+// TipoAlerta define a anomalia de qualidade de dados detetada numa sincronização
+type TipoAlerta string
+
+const (
+	AlertaVolumeAlto     TipoAlerta = "VOLUME_ALTO"     // Muito acima da mediana das sincronizações anteriores
+	AlertaVolumeBaixo    TipoAlerta = "VOLUME_BAIXO"    // Muito abaixo da mediana das sincronizações anteriores
+	AlertaSemRegistos    TipoAlerta = "SEM_REGISTOS"    // Nenhum registo numa fonte que costuma ter volume
+	AlertaTaxaRejeicao   TipoAlerta = "TAXA_REJEICAO"   // Demasiados registos recusados na normalização
+	AlertaFalhasSeguidas TipoAlerta = "FALHAS_SEGUIDAS" // Sincronizações falhadas consecutivas
+)
+
+// Erros da ingestão
+var (
+	ErrConnectorNotFound = errors.New("conector de registo público não encontrado")
+	ErrSyncInProgress    = errors.New("sincronização do conector já em curso")
+	ErrInvalidCursor     = errors.New("cursor de sincronização inválido")
+)
+
+// Registro é um registo público normalizado, convertido pelo Bureau em RegistroCredito
+//
+// O ID é derivado da fonte, do emissor e da referência do registo, pelo que a mesma
+// ocorrência recebida em várias sincronizações substitui a anterior
+type Registro struct {
+	ID             string            `json:"id"`
+	Fonte          Fonte             `json:"fonte"`
+	Conector       string            `json:"conector"`
+	Market         string            `json:"market"`
+	Documento      string            `json:"-"` // Apenas dígitos
+	TipoEntidade   string            `json:"tipoEntidade"`
+	Valor          float64           `json:"valor"`
+	DataOcorrencia time.Time         `json:"dataOcorrencia"`
+	Situacao       Situacao          `json:"situacao"`
+	FonteID        string            `json:"fonteId"`   // Código do cartório ou do órgão inscritor
+	FonteNome      string            `json:"fonteNome"` // Nome do cartório ou do órgão inscritor
+	Referencia     string            `json:"referencia"`
+	Detalhes       map[string]string `json:"detalhes,omitempty"`
+	AtualizadoEm   time.Time         `json:"atualizadoEm"`
+}
+
+// Rejeicao é um registo da fonte recusado na normalização
+type Rejeicao struct {
+	Posicao string `json:"posicao"` // Linha do ficheiro ou índice na página
+	Motivo  string `json:"motivo"`
+}
+
+// Lote é uma página ou um troço de ficheiro lido de uma fonte
+type Lote struct {
+	Registros  []Registro `json:"registros"`
+	Rejeicoes  []Rejeicao `json:"rejeicoes,omitempty"`
+	Cursor     string     `json:"cursor"` // Posição seguinte, gravada no checkpoint depois da ingestão
+	Disponivel bool       `json:"disponivel"`
+}
+
+// Checkpoint é a posição da última ingestão bem-sucedida de um conector
+type Checkpoint struct {
+	Conector      string    `json:"conector"`
+	Cursor        string    `json:"cursor"`
+	AtualizadoEm  time.Time `json:"atualizadoEm"`
+	TotalIngerido int64     `json:"totalIngerido"`
+}
+
+// Alerta é uma anomalia de qualidade de dados de uma sincronização
+type Alerta struct {
+	ID         string     `json:"id"`
+	Conector   string     `json:"conector"`
+	Fonte      Fonte      `json:"fonte"`
+	SyncID     string     `json:"syncId"`
+	Tipo       TipoAlerta `json:"tipo"`
+	Mensagem   string     `json:"mensagem"`
+	Observado  float64    `json:"observado"`
+	Referencia float64    `json:"referencia"` // Mediana, limite ou número de falhas que disparou o alerta
+	CriadoEm   time.Time  `json:"criadoEm"`
+}
+
+// SyncRun é o registo de uma sincronização de um conector
+type SyncRun struct {
+	ID            string     `json:"id"`
+	Conector      string     `json:"conector"`
+	Fonte         Fonte      `json:"fonte"`
+	Status        SyncStatus `json:"status"`
+	Manual        bool       `json:"manual"`
+	IniciadaEm    time.Time  `json:"iniciadaEm"`
+	ConcluidaEm   time.Time  `json:"concluidaEm"`
+	CursorInicial string     `json:"cursorInicial"`
+	CursorFinal   string     `json:"cursorFinal"`
+	Lotes         int        `json:"lotes"`
+	Ingeridos     int        `json:"ingeridos"`
+	Rejeitados    int        `json:"rejeitados"`
+	Baixados      int        `json:"baixados"` // Ingeridos com situação baixada
+	Volume        int        `json:"volume"`   // Lidos no ciclo, incluindo as sincronizações não concluídas anteriores
+	Rejeicoes     []Rejeicao `json:"rejeicoes,omitempty"`
+	Alertas       []Alerta   `json:"alertas,omitempty"`
+	Erro          string     `json:"erro,omitempty"`
+}
+
+// lidos é o volume da sincronização, base da deteção de anomalias
+func (r *SyncRun) lidos() int {
+	return r.Ingeridos + r.Rejeitados
+}
+
+// Sink recebe os registos normalizados
+// A gravação é idempotente pelo ID do registo: um lote repetido após uma falha não duplica registos
+type Sink interface {
+	Ingerir(ctx context.Context, registros []Registro) error
+}
+
+// AlertNotifier entrega os alertas de qualidade de dados à equipa de operação
+type AlertNotifier interface {
+	NotificarAlerta(ctx context.Context, alerta Alerta) error
+}
+
+// Store define a persistência dos checkpoints, do histórico das sincronizações e dos alertas
+type Store interface {
+	// GetCheckpoint recupera o checkpoint do conector; um conector nunca sincronizado tem cursor vazio
+	GetCheckpoint(ctx context.Context, conector string) (Checkpoint, error)
+
+	// SaveCheckpoint grava o checkpoint do conector
+	SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error
+
+	// SaveRun acrescenta uma sincronização ao histórico do conector
+	SaveRun(ctx context.Context, run *SyncRun) error
+
+	// ListRuns lista as sincronizações do conector, mais recentes primeiro
+	ListRuns(ctx context.Context, conector string, limit int) ([]*SyncRun, error)
+
+	// SaveAlerta grava um alerta de qualidade de dados
+	SaveAlerta(ctx context.Context, alerta Alerta) error
+
+	// ListAlertas lista os alertas, mais recentes primeiro; conector vazio lista os de todos
+	ListAlertas(ctx context.Context, conector string, limit int) ([]Alerta, error)
+}
+
+// InMemoryStore mantém os checkpoints, as últimas sincronizações e os últimos alertas em memória
+type InMemoryStore struct {
+	checkpoints map[string]Checkpoint
+	runs        map[string][]*SyncRun
+	alertas     []Alerta
+	maxHistory  int
+	mutex       sync.RWMutex
+}
+
+// NewInMemoryStore cria um armazenamento em memória que guarda até maxHistory sincronizações por
+// conector e alertas no total; um valor não positivo usa DefaultMaxHistory
+func NewInMemoryStore(maxHistory int) *InMemoryStore {
+	if maxHistory <= 0 {
+		maxHistory = DefaultMaxHistory
+	}
+	return &InMemoryStore{
+		checkpoints: make(map[string]Checkpoint),
+		runs:        make(map[string][]*SyncRun),
+		maxHistory:  maxHistory,
+	}
+}
+
+// GetCheckpoint retorna o checkpoint do conector
+func (s *InMemoryStore) GetCheckpoint(ctx context.Context, conector string) (Checkpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if checkpoint, ok := s.checkpoints[conector]; ok {
+		return checkpoint, nil
+	}
+	return Checkpoint{Conector: conector}, nil
+}
+
+// SaveCheckpoint grava o checkpoint do conector
+func (s *InMemoryStore) SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.checkpoints[checkpoint.Conector] = checkpoint
+	return nil
+}
+
+// SaveRun acrescenta a sincronização, descartando as mais antigas além do limite do histórico
+func (s *InMemoryStore) SaveRun(ctx context.Context, run *SyncRun) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	runs := append(s.runs[run.Conector], run.clone())
+	if len(runs) > s.maxHistory {
+		runs = runs[len(runs)-s.maxHistory:]
+	}
+	s.runs[run.Conector] = runs
+	return nil
+}
+
+// ListRuns retorna cópias das sincronizações do conector, mais recentes primeiro
+func (s *InMemoryStore) ListRuns(ctx context.Context, conector string, limit int) ([]*SyncRun, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	runs := s.runs[conector]
+	result := make([]*SyncRun, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		result = append(result, runs[i].clone())
+	}
+	return result, nil
+}
+
+// SaveAlerta grava o alerta, descartando os mais antigos além do limite do histórico
+func (s *InMemoryStore) SaveAlerta(ctx context.Context, alerta Alerta) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.alertas = append(s.alertas, alerta)
+	if len(s.alertas) > s.maxHistory {
+		s.alertas = s.alertas[len(s.alertas)-s.maxHistory:]
+	}
+	return nil
+}
+
+// ListAlertas retorna os alertas, mais recentes primeiro
+func (s *InMemoryStore) ListAlertas(ctx context.Context, conector string, limit int) ([]Alerta, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]Alerta, 0)
+	for i := len(s.alertas) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		if conector == "" || s.alertas[i].Conector == conector {
+			result = append(result, s.alertas[i])
+		}
+	}
+	return result, nil
+}
+
+// clone copia a sincronização, incluindo as rejeições e os alertas
+func (r *SyncRun) clone() *SyncRun {
+	copied := *r
+	copied.Rejeicoes = append([]Rejeicao(nil), r.Rejeicoes...)
+	copied.Alertas = append([]Alerta(nil), r.Alertas...)
+	return &copied
+}
+
+// Index mantém os registos públicos ingeridos por documento e serve as consultas do Bureau
+type Index struct {
+	registros    map[string]Registro
+	porDocumento map[string]map[string]bool
+	mutex        sync.RWMutex
+}
+
+// NewIndex cria um índice em memória dos registos públicos
+func NewIndex() *Index {
+	return &Index{
+		registros:    make(map[string]Registro),
+		porDocumento: make(map[string]map[string]bool),
+	}
+}
+
+// Ingerir grava os registos, substituindo as versões anteriores com o mesmo ID
+func (idx *Index) Ingerir(ctx context.Context, registros []Registro) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	for _, registro := range registros {
+		if anterior, ok := idx.registros[registro.ID]; ok && anterior.Documento != registro.Documento {
+			delete(idx.porDocumento[anterior.Documento], registro.ID)
+		}
+		idx.registros[registro.ID] = registro
+		if idx.porDocumento[registro.Documento] == nil {
+			idx.porDocumento[registro.Documento] = make(map[string]bool)
+		}
+		idx.porDocumento[registro.Documento][registro.ID] = true
+	}
+	return nil
+}
+
+// Restricoes retorna os registos ativos do documento no mercado, os mais recentes primeiro
+// O documento pode ter formatação; apenas os dígitos são comparados
+func (idx *Index) Restricoes(market, documento string) []Registro {
+	documento = normalizeDocumento(documento)
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	var result []Registro
+	for id := range idx.porDocumento[documento] {
+		registro := idx.registros[id]
+		if registro.Situacao != SituacaoAtiva || (market != "" && registro.Market != "" && !strings.EqualFold(registro.Market, market)) {
+			continue
+		}
+		registro.Detalhes = copyDetalhes(registro.Detalhes)
+		result = append(result, registro)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DataOcorrencia.Equal(result[j].DataOcorrencia) {
+			return result[i].DataOcorrencia.After(result[j].DataOcorrencia)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Total retorna o número de registos no índice, incluindo os baixados
+func (idx *Index) Total() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return len(idx.registros)
+}
+
+// copyDetalhes copia os detalhes do registo
+func copyDetalhes(detalhes map[string]string) map[string]string {
+	if detalhes == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(detalhes))
+	for key, value := range detalhes {
+		copied[key] = value
+	}
+	return copied
+}
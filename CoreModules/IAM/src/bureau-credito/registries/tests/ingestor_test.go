@@ -0,0 +1,469 @@
+/**
+ * @file ingestor_test.go
+ * @description Testes da ingestão de registos públicos (protestos e dívida ativa) do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/registries"
+)
+
+// testClock é um relógio ajustável para o agendamento das sincronizações
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// flakySink delega no índice e falha as chamadas configuradas
+type flakySink struct {
+	index  *registries.Index
+	mutex  sync.Mutex
+	falhar int
+}
+
+func (s *flakySink) Ingerir(ctx context.Context, registros []registries.Registro) error {
+	s.mutex.Lock()
+	if s.falhar > 0 {
+		s.falhar--
+		s.mutex.Unlock()
+		return errors.New("armazenamento indisponível")
+	}
+	s.mutex.Unlock()
+	return s.index.Ingerir(ctx, registros)
+}
+
+// volumeConnector devolve, em cada sincronização, um único lote com o volume configurado
+type volumeConnector struct {
+	volumes    []int
+	rejeitados []int
+	errs       []error
+	sync       int
+}
+
+func (c *volumeConnector) Name() string            { return "volume" }
+func (c *volumeConnector) Fonte() registries.Fonte { return registries.FonteProtesto }
+
+func (c *volumeConnector) Fetch(ctx context.Context, cursor string) (*registries.Lote, error) {
+	n := c.sync
+	c.sync++
+	if n < len(c.errs) && c.errs[n] != nil {
+		return nil, c.errs[n]
+	}
+	lote := &registries.Lote{Cursor: strconv.Itoa(n + 1)}
+	for i := 0; i < c.volumes[n]; i++ {
+		lote.Registros = append(lote.Registros, registries.Registro{
+			ID:        fmt.Sprintf("%d-%d", n, i),
+			Documento: "12345678901",
+			Situacao:  registries.SituacaoAtiva,
+		})
+	}
+	if n < len(c.rejeitados) {
+		for i := 0; i < c.rejeitados[n]; i++ {
+			lote.Rejeicoes = append(lote.Rejeicoes, registries.Rejeicao{Posicao: strconv.Itoa(i), Motivo: "documento inválido"})
+		}
+	}
+	return lote, nil
+}
+
+// recordingNotifier regista os alertas notificados
+type recordingNotifier struct {
+	mutex   sync.Mutex
+	alertas []registries.Alerta
+}
+
+func (n *recordingNotifier) NotificarAlerta(ctx context.Context, alerta registries.Alerta) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.alertas = append(n.alertas, alerta)
+	return nil
+}
+
+// protestoServer simula a API da central de cartórios, com as alterações ordenadas por sequência
+type protestoServer struct {
+	mutex   sync.Mutex
+	titulos []map[string]interface{}
+	pedidos []string
+}
+
+func (s *protestoServer) add(titulo map[string]interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.titulos = append(s.titulos, titulo)
+}
+
+func (s *protestoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(registries.APIKeyHeader) != "chave-cartorios" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pedidos = append(s.pedidos, r.URL.RawQuery)
+
+	desde, _ := strconv.Atoi(r.URL.Query().Get("desde"))
+	limite, _ := strconv.Atoi(r.URL.Query().Get("limite"))
+	fim := desde + limite
+	if fim > len(s.titulos) {
+		fim = len(s.titulos)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"titulos":       s.titulos[desde:fim],
+		"proximoCursor": strconv.Itoa(fim),
+		"temMais":       fim < len(s.titulos),
+	})
+}
+
+func titulo(protocolo, documento, situacao string, valor float64) map[string]interface{} {
+	return map[string]interface{}{
+		"protocolo":        protocolo,
+		"documentoDevedor": documento,
+		"valor":            valor,
+		"dataProtesto":     "2025-03-10",
+		"situacao":         situacao,
+		"cartorioCodigo":   "SP-01",
+		"cartorioNome":     "1º Tabelião de Protesto de São Paulo",
+		"uf":               "SP",
+	}
+}
+
+func newIngestor(t *testing.T, sink registries.Sink, notifier registries.AlertNotifier, config registries.Config) (*registries.Ingestor, *registries.InMemoryStore, *testClock) {
+	t.Helper()
+	clock := &testClock{now: time.Date(2025, 4, 1, 6, 0, 0, 0, time.UTC)}
+	store := registries.NewInMemoryStore(0)
+	ingestor := registries.NewIngestor(store, sink, notifier, config)
+	ingestor.SetClock(clock.Now)
+	return ingestor, store, clock
+}
+
+func TestProtestoAPIConnectorIncrementalSync(t *testing.T) {
+	server := &protestoServer{}
+	server.add(titulo("P-1", "123.456.789-01", "PROTESTADO", 1500.50))
+	server.add(titulo("P-2", "12.345.678/0001-90", "PROTESTADO", 8200))
+	server.add(titulo("P-3", "123", "PROTESTADO", 10))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	index := registries.NewIndex()
+	ingestor, store, clock := newIngestor(t, index, nil, registries.DefaultConfig())
+	connector := registries.NewProtestoAPIConnector(httpServer.Client(), registries.ProtestoAPIConfig{
+		Endpoint: httpServer.URL + "/protestos",
+		APIKey:   "chave-cartorios",
+		PageSize: 2,
+	})
+	require.NoError(t, ingestor.Register(connector, time.Hour))
+
+	ctx := context.Background()
+	run, err := ingestor.Sync(ctx, "protesto-api")
+	require.NoError(t, err)
+	assert.Equal(t, registries.SyncCompleted, run.Status)
+	assert.Equal(t, 2, run.Lotes)
+	assert.Equal(t, 2, run.Ingeridos)
+	assert.Equal(t, 1, run.Rejeitados)
+	assert.Equal(t, "documento inválido", run.Rejeicoes[0].Motivo)
+	assert.Equal(t, "3", run.CursorFinal)
+	assert.Equal(t, []string{"limite=2", "desde=2&limite=2"}, server.pedidos)
+
+	restricoes := index.Restricoes("Brazil", "12345678901")
+	require.Len(t, restricoes, 1)
+	assert.Equal(t, registries.FonteProtesto, restricoes[0].Fonte)
+	assert.Equal(t, "PF", restricoes[0].TipoEntidade)
+	assert.Equal(t, 1500.50, restricoes[0].Valor)
+	assert.Equal(t, "SP-01", restricoes[0].FonteID)
+	assert.Equal(t, "P-1", restricoes[0].Referencia)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), restricoes[0].DataOcorrencia)
+	assert.Equal(t, clock.Now(), restricoes[0].AtualizadoEm)
+	assert.Equal(t, "PJ", index.Restricoes("brazil", "12345678000190")[0].TipoEntidade)
+	assert.Empty(t, index.Restricoes("angola", "12345678901"))
+
+	checkpoint, err := store.GetCheckpoint(ctx, "protesto-api")
+	require.NoError(t, err)
+	assert.Equal(t, "3", checkpoint.Cursor)
+	assert.Equal(t, int64(2), checkpoint.TotalIngerido)
+
+	// O cancelamento chega como alteração do mesmo protocolo e baixa o protesto
+	server.add(titulo("P-1", "123.456.789-01", "CANCELADO", 1500.50))
+	clock.Advance(time.Hour)
+	assert.Equal(t, 1, ingestor.RunOnce(ctx))
+
+	runs, err := ingestor.ListRuns(ctx, "protesto-api", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "3", runs[0].CursorInicial)
+	assert.Equal(t, 1, runs[0].Ingeridos)
+	assert.Equal(t, 1, runs[0].Baixados)
+	assert.Equal(t, "desde=3&limite=2", server.pedidos[len(server.pedidos)-1])
+	assert.Empty(t, index.Restricoes("brazil", "123.456.789-01"))
+	assert.Equal(t, 2, index.Total())
+
+	// Antes do intervalo nada é sincronizado
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, 0, ingestor.RunOnce(ctx))
+}
+
+func TestProtestoAPIConnectorUnavailable(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	ingestor, store, _ := newIngestor(t, registries.NewIndex(), nil, registries.DefaultConfig())
+	connector := registries.NewProtestoAPIConnector(httpServer.Client(), registries.ProtestoAPIConfig{Endpoint: httpServer.URL})
+	require.NoError(t, ingestor.Register(connector, time.Hour))
+
+	run, err := ingestor.Sync(context.Background(), "protesto-api")
+	require.Error(t, err)
+	assert.Equal(t, registries.SyncFailed, run.Status)
+	assert.Contains(t, run.Erro, "respondeu 503")
+
+	checkpoint, err := store.GetCheckpoint(context.Background(), "protesto-api")
+	require.NoError(t, err)
+	assert.Empty(t, checkpoint.Cursor)
+}
+
+const dividaAtivaHeader = "CPF_CNPJ;TIPO_PESSOA;NOME_DEVEDOR;NUMERO_INSCRICAO;SITUACAO_INSCRICAO;DATA_INSCRICAO;VALOR_CONSOLIDADO;UNIDADE_RESPONSAVEL\n"
+
+func TestDividaAtivaFileConnectorResumesFromCheckpoint(t *testing.T) {
+	files := fstest.MapFS{
+		"pgfn-2025-01.csv": {Data: []byte("\ufeff" + dividaAtivaHeader +
+			"123.456.789-01;Pessoa física;Fulano;80 1 24 000001-01;ATIVA EM COBRANCA;15/01/2024;1.234,56;PRFN 3ª Região\n" +
+			"XXX.456.789-XX;Pessoa física;Beltrano;80 1 24 000002-01;ATIVA EM COBRANCA;15/01/2024;500,00;PRFN 3ª Região\n" +
+			"12.345.678/0001-90;Pessoa jurídica;Empresa;80 2 24 000003-01;ATIVA AJUIZADA;20/02/2024;98.765,43;PRFN 3ª Região\n")},
+		"leia-me.txt": {Data: []byte("ignorado")},
+	}
+
+	index := registries.NewIndex()
+	sink := &flakySink{index: index, falhar: 1}
+	config := registries.DefaultConfig()
+	ingestor, store, clock := newIngestor(t, sink, nil, config)
+	connector := registries.NewDividaAtivaFileConnector(files, registries.DividaAtivaFileConfig{PageSize: 2})
+	require.NoError(t, ingestor.Register(connector, 24*time.Hour))
+
+	ctx := context.Background()
+
+	// A falha do armazenamento não avança o checkpoint
+	run, err := ingestor.Sync(ctx, "divida-ativa-arquivos")
+	require.Error(t, err)
+	assert.Equal(t, registries.SyncFailed, run.Status)
+	checkpoint, err := store.GetCheckpoint(ctx, "divida-ativa-arquivos")
+	require.NoError(t, err)
+	assert.Empty(t, checkpoint.Cursor)
+
+	run, err = ingestor.Sync(ctx, "divida-ativa-arquivos")
+	require.NoError(t, err)
+	assert.Equal(t, registries.SyncCompleted, run.Status)
+	assert.Equal(t, 2, run.Ingeridos)
+	assert.Equal(t, 1, run.Rejeitados)
+	assert.Equal(t, "documento mascarado", run.Rejeicoes[0].Motivo)
+	assert.Equal(t, "pgfn-2025-01.csv#2", run.Rejeicoes[0].Posicao)
+	assert.Equal(t, "pgfn-2025-01.csv#fim", run.CursorFinal)
+
+	restricoes := index.Restricoes("brazil", "12345678000190")
+	require.Len(t, restricoes, 1)
+	assert.Equal(t, registries.FonteDividaAtiva, restricoes[0].Fonte)
+	assert.Equal(t, 98765.43, restricoes[0].Valor)
+	assert.Equal(t, "80 2 24 000003-01", restricoes[0].Referencia)
+	assert.Equal(t, "PGFN", restricoes[0].FonteID)
+	assert.Equal(t, "ATIVA AJUIZADA", restricoes[0].Detalhes["situacaoOrigem"])
+	assert.Equal(t, "pgfn-2025-01.csv", restricoes[0].Detalhes["arquivo"])
+	assert.NotContains(t, restricoes[0].Detalhes, "nomeDevedor")
+
+	// Uma nova extração traz a inscrição extinta e um devedor novo; o ficheiro já lido não é relido
+	files["pgfn-2025-02.csv"] = &fstest.MapFile{Data: []byte(dividaAtivaHeader +
+		"123.456.789-01;Pessoa física;Fulano;80 1 24 000001-01;EXTINTA POR PAGAMENTO;15/01/2024;1.234,56;PRFN 3ª Região\n" +
+		"987.654.321-00;Pessoa física;Sicrano;80 1 25 000009-01;ATIVA EM COBRANCA;2025-02-03;750.10;PRFN 1ª Região\n")}
+	clock.Advance(24 * time.Hour)
+	assert.Equal(t, 1, ingestor.RunOnce(ctx))
+
+	runs, err := ingestor.ListRuns(ctx, "divida-ativa-arquivos", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "pgfn-2025-01.csv#fim", runs[0].CursorInicial)
+	assert.Equal(t, "pgfn-2025-02.csv#fim", runs[0].CursorFinal)
+	assert.Equal(t, 2, runs[0].Ingeridos)
+	assert.Equal(t, 1, runs[0].Baixados)
+	assert.Empty(t, index.Restricoes("brazil", "12345678901"))
+	assert.Equal(t, 750.10, index.Restricoes("brazil", "98765432100")[0].Valor)
+	assert.Equal(t, 3, index.Total())
+}
+
+func TestDividaAtivaFileConnectorRejectsInvalidCursor(t *testing.T) {
+	files := fstest.MapFS{"pgfn.csv": {Data: []byte(dividaAtivaHeader)}}
+	connector := registries.NewDividaAtivaFileConnector(files, registries.DividaAtivaFileConfig{})
+
+	_, err := connector.Fetch(context.Background(), "pgfn.csv")
+	assert.ErrorIs(t, err, registries.ErrInvalidCursor)
+	_, err = connector.Fetch(context.Background(), "pgfn.csv#5")
+	assert.ErrorIs(t, err, registries.ErrInvalidCursor)
+
+	lote, err := connector.Fetch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, lote.Registros)
+	assert.False(t, lote.Disponivel)
+	assert.Equal(t, "pgfn.csv#fim", lote.Cursor)
+}
+
+func TestIngestorPartialSyncContinuesOnNextCycle(t *testing.T) {
+	server := &protestoServer{}
+	for i := 1; i <= 5; i++ {
+		server.add(titulo(fmt.Sprintf("P-%d", i), fmt.Sprintf("1234567890%d", i), "PROTESTADO", 100))
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	config := registries.DefaultConfig()
+	config.MaxLotesPorSync = 2
+	ingestor, _, _ := newIngestor(t, registries.NewIndex(), nil, config)
+	connector := registries.NewProtestoAPIConnector(httpServer.Client(), registries.ProtestoAPIConfig{
+		Endpoint: httpServer.URL, APIKey: "chave-cartorios", PageSize: 2,
+	})
+	require.NoError(t, ingestor.Register(connector, time.Hour))
+
+	ctx := context.Background()
+	assert.Equal(t, 1, ingestor.RunOnce(ctx))
+	// A sincronização parcial fica vencida e continua no ciclo seguinte
+	assert.Equal(t, 1, ingestor.RunOnce(ctx))
+	assert.Equal(t, 0, ingestor.RunOnce(ctx))
+
+	runs, err := ingestor.ListRuns(ctx, "protesto-api", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, registries.SyncPartial, runs[1].Status)
+	assert.Equal(t, 4, runs[1].Ingeridos)
+	assert.Equal(t, registries.SyncCompleted, runs[0].Status)
+	assert.Equal(t, 1, runs[0].Ingeridos)
+	assert.Equal(t, 5, runs[0].Volume)
+}
+
+func TestIngestorRaisesDataQualityAlerts(t *testing.T) {
+	connector := &volumeConnector{
+		volumes:    []int{100, 120, 90, 110, 450, 0, 20, 100, 0, 0, 0},
+		rejeitados: []int{0, 0, 0, 0, 0, 0, 0, 20},
+		errs:       []error{8: errors.New("timeout"), 9: errors.New("timeout"), 10: errors.New("timeout")},
+	}
+	notifier := &recordingNotifier{}
+	ingestor, _, _ := newIngestor(t, registries.NewIndex(), notifier, registries.DefaultConfig())
+	require.NoError(t, ingestor.Register(connector, time.Hour))
+
+	ctx := context.Background()
+	var tipos [][]registries.TipoAlerta
+	for i := 0; i < len(connector.volumes); i++ {
+		run, _ := ingestor.Sync(ctx, "volume")
+		var sync []registries.TipoAlerta
+		for _, alerta := range run.Alertas {
+			sync = append(sync, alerta.Tipo)
+			assert.Equal(t, run.ID, alerta.SyncID)
+		}
+		tipos = append(tipos, sync)
+	}
+
+	// Sem histórico suficiente nenhuma sincronização é avaliada
+	assert.Empty(t, tipos[0])
+	assert.Empty(t, tipos[2])
+	assert.Empty(t, tipos[3])
+	assert.Equal(t, []registries.TipoAlerta{registries.AlertaVolumeAlto}, tipos[4])
+	assert.Equal(t, []registries.TipoAlerta{registries.AlertaSemRegistos}, tipos[5])
+	assert.Equal(t, []registries.TipoAlerta{registries.AlertaVolumeBaixo}, tipos[6])
+	assert.Equal(t, []registries.TipoAlerta{registries.AlertaTaxaRejeicao}, tipos[7])
+	// O alerta de falhas é emitido uma vez, ao atingir o limite
+	assert.Empty(t, tipos[8])
+	assert.Empty(t, tipos[9])
+	assert.Equal(t, []registries.TipoAlerta{registries.AlertaFalhasSeguidas}, tipos[10])
+
+	assert.Len(t, notifier.alertas, 5)
+	alertas, err := ingestor.ListAlertas(ctx, "volume", 2)
+	require.NoError(t, err)
+	require.Len(t, alertas, 2)
+	assert.Equal(t, registries.AlertaFalhasSeguidas, alertas[0].Tipo)
+	assert.Equal(t, registries.AlertaTaxaRejeicao, alertas[1].Tipo)
+	assert.InDelta(t, 0.1667, alertas[1].Observado, 0.001)
+
+	_, err = ingestor.ListAlertas(ctx, "desconhecido", 0)
+	assert.ErrorIs(t, err, registries.ErrConnectorNotFound)
+}
+
+func TestRegistriesHandler(t *testing.T) {
+	server := &protestoServer{}
+	server.add(titulo("P-1", "12345678901", "PROTESTADO", 100))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	ingestor, _, _ := newIngestor(t, registries.NewIndex(), nil, registries.DefaultConfig())
+	require.NoError(t, ingestor.Register(registries.NewProtestoAPIConnector(httpServer.Client(), registries.ProtestoAPIConfig{
+		Endpoint: httpServer.URL, APIKey: "chave-cartorios",
+	}), time.Hour))
+	require.NoError(t, ingestor.Register(registries.NewProtestoAPIConnector(httpServer.Client(), registries.ProtestoAPIConfig{
+		Name: "protesto-sem-chave", Endpoint: httpServer.URL,
+	}), time.Hour))
+	assert.Error(t, ingestor.Register(registries.NewProtestoAPIConnector(nil, registries.ProtestoAPIConfig{}), time.Hour))
+
+	mux := http.NewServeMux()
+	registries.NewHandler(ingestor).Register(mux)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/registries/connectors/protesto-api/sync")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var run registries.SyncRun
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &run))
+	assert.Equal(t, registries.SyncCompleted, run.Status)
+	assert.Equal(t, 1, run.Ingeridos)
+
+	rec = do(http.MethodPost, "/registries/connectors/protesto-sem-chave/sync")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	rec = do(http.MethodGet, "/registries/connectors")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var connectors []registries.ConnectorStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &connectors))
+	require.Len(t, connectors, 2)
+	assert.Equal(t, "protesto-api", connectors[0].Nome)
+	assert.Equal(t, "1", connectors[0].Checkpoint.Cursor)
+	assert.Equal(t, "1h0m0s", connectors[0].Intervalo)
+
+	rec = do(http.MethodGet, "/registries/connectors/protesto-api/runs?limit=5")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runs []registries.SyncRun
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runs))
+	assert.Len(t, runs, 1)
+
+	rec = do(http.MethodGet, "/registries/alerts")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/registries/connectors/desconhecido/sync").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/registries/connectors/protesto-api").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, "/registries/connectors/protesto-api/sync").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/registries/alerts?limit=0").Code)
+}
@@ -0,0 +1,500 @@
+/**
+ * @file generator.go
+ * @description Worker de geração assíncrona de relatórios de consulta com armazenamento de objetos
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Valores padrão do gerador de relatórios
+const (
+	DefaultReportWorkers     = 2
+	DefaultReportQueueSize   = 100
+	DefaultReportMaxAttempts = 3
+	DefaultReportRetryDelay  = 2 * time.Second
+	DefaultPurgeInterval     = time.Hour
+	DefaultReportKeyPrefix   = "bureau-credito/relatorios"
+	DefaultReportMarket      = "global"
+
+	// Margem antes da expiração em que a URL pré-assinada é renovada
+	urlRefreshMargin = 30 * time.Second
+)
+
+// JobStore define a persistência dos jobs de geração de relatório
+type JobStore interface {
+	// Save cria ou atualiza um job
+	Save(ctx context.Context, job *ReportJob) error
+
+	// Get recupera um job pelo ID
+	Get(ctx context.Context, jobID string) (*ReportJob, error)
+
+	// ListByStatus lista os jobs num estado, por ordem de criação
+	ListByStatus(ctx context.Context, status JobStatus) ([]*ReportJob, error)
+}
+
+// InMemoryJobStore mantém os jobs em memória
+type InMemoryJobStore struct {
+	jobs  map[string]*ReportJob
+	mutex sync.RWMutex
+}
+
+// NewInMemoryJobStore cria um armazenamento de jobs em memória
+func NewInMemoryJobStore() *InMemoryJobStore {
+	return &InMemoryJobStore{jobs: make(map[string]*ReportJob)}
+}
+
+// Save grava uma cópia do job
+func (s *InMemoryJobStore) Save(ctx context.Context, job *ReportJob) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *job
+	s.jobs[job.JobID] = &copied
+	return nil
+}
+
+// Get retorna uma cópia do job
+func (s *InMemoryJobStore) Get(ctx context.Context, jobID string) (*ReportJob, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// ListByStatus retorna cópias dos jobs no estado indicado
+func (s *InMemoryJobStore) ListByStatus(ctx context.Context, status JobStatus) ([]*ReportJob, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var jobs []*ReportJob
+	for _, job := range s.jobs {
+		if job.Status == status {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, nil
+}
+
+// GeneratorConfig define o comportamento do gerador de relatórios
+type GeneratorConfig struct {
+	Workers       int                        `json:"workers"`
+	QueueSize     int                        `json:"queueSize"`
+	MaxAttempts   int                        `json:"maxAttempts"`
+	RetryDelay    time.Duration              `json:"retryDelay"`
+	PurgeInterval time.Duration              `json:"purgeInterval"`
+	KeyPrefix     string                     `json:"keyPrefix"`
+	DefaultMarket string                     `json:"defaultMarket"` // Política usada para mercados sem regra própria
+	Policies      map[string]RetentionPolicy `json:"policies"`      // Por mercado, em minúsculas
+}
+
+// DefaultGeneratorConfig retorna a configuração padrão
+func DefaultGeneratorConfig() GeneratorConfig {
+	return GeneratorConfig{
+		Workers:       DefaultReportWorkers,
+		QueueSize:     DefaultReportQueueSize,
+		MaxAttempts:   DefaultReportMaxAttempts,
+		RetryDelay:    DefaultReportRetryDelay,
+		PurgeInterval: DefaultPurgeInterval,
+		KeyPrefix:     DefaultReportKeyPrefix,
+		DefaultMarket: DefaultReportMarket,
+		Policies:      DefaultRetentionPolicies(),
+	}
+}
+
+// policyFor retorna a política de retenção do mercado
+func (c GeneratorConfig) policyFor(market string) (RetentionPolicy, error) {
+	if policy, ok := c.Policies[strings.ToLower(market)]; ok {
+		return policy, nil
+	}
+	if policy, ok := c.Policies[strings.ToLower(c.DefaultMarket)]; ok {
+		return policy, nil
+	}
+	return RetentionPolicy{}, fmt.Errorf("%w: sem política de retenção para o mercado %s", ErrInvalidReport, market)
+}
+
+// ReportMetrics contém as métricas Prometheus da geração de relatórios
+type ReportMetrics struct {
+	jobsCounter   *prometheus.CounterVec
+	durationHist  *prometheus.HistogramVec
+	purgedCounter *prometheus.CounterVec
+	queueDepth    prometheus.Gauge
+}
+
+// NewReportMetrics cria e regista as métricas de geração de relatórios
+func NewReportMetrics(registry *prometheus.Registry) *ReportMetrics {
+	m := &ReportMetrics{
+		jobsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_report_jobs_total",
+				Help: "Número total de jobs de relatório terminados, por formato, mercado e estado final",
+			},
+			[]string{"format", "market", "status"},
+		),
+		durationHist: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "bureau_credito_report_generation_duration_seconds",
+				Help:    "Duração da renderização e gravação dos relatórios por formato",
+				Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms a ~20s
+			},
+			[]string{"format"},
+		),
+		purgedCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_reports_purged_total",
+				Help: "Número total de relatórios removidos ao fim do prazo de retenção, por mercado",
+			},
+			[]string{"market"},
+		),
+		queueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_report_queue_depth",
+				Help: "Número de jobs de relatório aguardando um worker",
+			},
+		),
+	}
+
+	registry.MustRegister(m.jobsCounter, m.durationHist, m.purgedCounter, m.queueDepth)
+	return m
+}
+
+// observeJob regista o estado final e a duração de um job
+func (m *ReportMetrics) observeJob(job *ReportJob, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.jobsCounter.WithLabelValues(string(job.Format), job.Market, string(job.Status)).Inc()
+	if job.Status == JobCompleted {
+		m.durationHist.WithLabelValues(string(job.Format)).Observe(duration.Seconds())
+	}
+}
+
+// observePurge regista a remoção de um relatório expirado
+func (m *ReportMetrics) observePurge(market string) {
+	if m == nil {
+		return
+	}
+	m.purgedCounter.WithLabelValues(market).Inc()
+}
+
+// observeQueue regista a profundidade da fila
+func (m *ReportMetrics) observeQueue(depth int) {
+	if m == nil {
+		return
+	}
+	m.queueDepth.Set(float64(depth))
+}
+
+// reportTask é um pedido de geração na fila; os dados da consulta só existem em memória
+type reportTask struct {
+	jobID string
+	data  ReportData
+}
+
+// ReportGenerator gera relatórios de consulta em background, grava-os no armazenamento
+// de objetos e disponibiliza URLs pré-assinadas com validade limitada pela retenção do mercado
+type ReportGenerator struct {
+	storage ObjectStorage
+	jobs    JobStore
+	config  GeneratorConfig
+	metrics *ReportMetrics
+	queue   chan reportTask
+	now     func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReportGenerator cria o gerador de relatórios; metrics pode ser nil
+func NewReportGenerator(storage ObjectStorage, jobs JobStore, config GeneratorConfig, metrics *ReportMetrics) *ReportGenerator {
+	defaults := DefaultGeneratorConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaults.QueueSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.PurgeInterval <= 0 {
+		config.PurgeInterval = defaults.PurgeInterval
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = defaults.KeyPrefix
+	}
+	if config.DefaultMarket == "" {
+		config.DefaultMarket = defaults.DefaultMarket
+	}
+	if len(config.Policies) == 0 {
+		config.Policies = defaults.Policies
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ReportGenerator{
+		storage: storage,
+		jobs:    jobs,
+		config:  config,
+		metrics: metrics,
+		queue:   make(chan reportTask, config.QueueSize),
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetClock substitui o relógio usado nos prazos de retenção e validade das URLs
+func (g *ReportGenerator) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// Start inicia os workers de geração e a limpeza periódica de relatórios expirados
+func (g *ReportGenerator) Start() {
+	for i := 0; i < g.config.Workers; i++ {
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			for {
+				select {
+				case <-g.ctx.Done():
+					return
+				case task := <-g.queue:
+					g.metrics.observeQueue(len(g.queue))
+					g.process(g.ctx, task)
+				}
+			}
+		}()
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		ticker := time.NewTicker(g.config.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.ctx.Done():
+				return
+			case <-ticker.C:
+				g.PurgeExpired(g.ctx)
+			}
+		}
+	}()
+}
+
+// Stop interrompe os workers; jobs ainda na fila permanecem PENDING
+func (g *ReportGenerator) Stop() {
+	g.cancel()
+	g.wg.Wait()
+}
+
+// Submit regista um job de geração e coloca-o na fila
+func (g *ReportGenerator) Submit(ctx context.Context, data ReportData, format Format) (*ReportJob, error) {
+	if format != FormatHTML && format != FormatPDF {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err := data.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	if _, err := g.config.policyFor(data.Market); err != nil {
+		return nil, err
+	}
+
+	job := &ReportJob{
+		JobID:      uuid.New().String(),
+		ConsultaID: data.ConsultaID,
+		TenantID:   data.TenantID,
+		Market:     strings.ToLower(data.Market),
+		Format:     format,
+		Status:     JobPending,
+		CreatedAt:  g.now(),
+	}
+	if err := g.jobs.Save(ctx, job); err != nil {
+		return nil, fmt.Errorf("falha ao gravar job de relatório: %w", err)
+	}
+
+	select {
+	case g.queue <- reportTask{jobID: job.JobID, data: data}:
+		g.metrics.observeQueue(len(g.queue))
+	default:
+		job.Status = JobFailed
+		job.Error = ErrQueueFull.Error()
+		g.jobs.Save(ctx, job)
+		g.metrics.observeJob(job, 0)
+		return nil, ErrQueueFull
+	}
+
+	return job, nil
+}
+
+// GetJob retorna o estado do job; para relatórios concluídos, renova a URL pré-assinada
+// quando expirada, enquanto o relatório estiver dentro do prazo de retenção
+func (g *ReportGenerator) GetJob(ctx context.Context, jobID string) (*ReportJob, error) {
+	job, err := g.jobs.Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobCompleted {
+		return job, nil
+	}
+
+	now := g.now()
+	if job.RetainUntil != nil && !now.Before(*job.RetainUntil) {
+		return job, ErrReportExpired
+	}
+	if job.URLExpiresAt != nil && now.Add(urlRefreshMargin).Before(*job.URLExpiresAt) {
+		return job, nil
+	}
+
+	if err := g.presign(ctx, job, now); err != nil {
+		return nil, err
+	}
+	if err := g.jobs.Save(ctx, job); err != nil {
+		return nil, fmt.Errorf("falha ao gravar job de relatório: %w", err)
+	}
+	return job, nil
+}
+
+// PurgeExpired remove os relatórios cujo prazo de retenção terminou e retorna quantos foram removidos
+func (g *ReportGenerator) PurgeExpired(ctx context.Context) (int, error) {
+	jobs, err := g.jobs.ListByStatus(ctx, JobCompleted)
+	if err != nil {
+		return 0, err
+	}
+
+	now := g.now()
+	purged := 0
+	var errs []error
+	for _, job := range jobs {
+		if job.RetainUntil == nil || now.Before(*job.RetainUntil) {
+			continue
+		}
+		if err := g.storage.DeleteObject(ctx, job.ObjectKey); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.JobID, err))
+			continue
+		}
+		job.Status = JobExpired
+		job.URL = ""
+		job.URLExpiresAt = nil
+		if err := g.jobs.Save(ctx, job); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.JobID, err))
+			continue
+		}
+		g.metrics.observePurge(job.Market)
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// process gera um relatório, com novas tentativas em caso de falha
+func (g *ReportGenerator) process(ctx context.Context, task reportTask) {
+	job, err := g.jobs.Get(ctx, task.jobID)
+	if err != nil {
+		return
+	}
+
+	started := g.now()
+	job.Status = JobRunning
+	job.StartedAt = &started
+	g.jobs.Save(ctx, job)
+
+	for {
+		job.Attempts++
+		err = g.generate(ctx, job, task.data)
+		if err == nil || job.Attempts >= g.config.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(g.config.RetryDelay):
+		}
+	}
+
+	completed := g.now()
+	job.CompletedAt = &completed
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobCompleted
+		job.Error = ""
+	}
+	g.jobs.Save(context.Background(), job)
+	g.metrics.observeJob(job, completed.Sub(started))
+}
+
+// generate renderiza o relatório, grava o objeto e gera a primeira URL
+func (g *ReportGenerator) generate(ctx context.Context, job *ReportJob, data ReportData) error {
+	policy, err := g.config.policyFor(job.Market)
+	if err != nil {
+		return err
+	}
+
+	body, err := Render(job.Format, data)
+	if err != nil {
+		return err
+	}
+
+	now := g.now()
+	retainUntil := now.Add(policy.Retention)
+	key := fmt.Sprintf("%s/%s/%s/%s.%s", g.config.KeyPrefix, job.Market, job.ConsultaID, job.JobID, job.Format)
+	metadata := map[string]string{
+		"consulta-id":  job.ConsultaID,
+		"market":       job.Market,
+		"retain-until": retainUntil.UTC().Format(time.RFC3339),
+	}
+	if job.TenantID != "" {
+		metadata["tenant-id"] = job.TenantID
+	}
+
+	if err := g.storage.PutObject(ctx, key, body, job.Format.ContentType(), metadata); err != nil {
+		return fmt.Errorf("falha ao gravar relatório: %w", err)
+	}
+
+	job.ObjectKey = key
+	job.Size = len(body)
+	job.RetainUntil = &retainUntil
+	return g.presign(ctx, job, now)
+}
+
+// presign gera uma nova URL pré-assinada com validade limitada pela retenção
+func (g *ReportGenerator) presign(ctx context.Context, job *ReportJob, now time.Time) error {
+	policy, err := g.config.policyFor(job.Market)
+	if err != nil {
+		return err
+	}
+	expiry := policy.urlExpiry(now, *job.RetainUntil)
+
+	url, err := g.storage.PresignGetURL(ctx, job.ObjectKey, expiry)
+	if err != nil {
+		return fmt.Errorf("falha ao gerar URL do relatório: %w", err)
+	}
+	expiresAt := now.Add(expiry)
+	job.URL = url
+	job.URLExpiresAt = &expiresAt
+	return nil
+}
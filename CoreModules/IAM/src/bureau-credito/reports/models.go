@@ -0,0 +1,142 @@
+/**
+ * @file models.go
+ * @description Modelos da geração assíncrona de relatórios de consulta do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package reports
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Format define o formato de um relatório de consulta
+type Format string
+
+const (
+	FormatHTML Format = "html"
+	FormatPDF  Format = "pdf"
+)
+
+// ContentType retorna o tipo de conteúdo do formato
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "text/html; charset=utf-8"
+	}
+}
+
+// JobStatus define o estado de um job de geração de relatório
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "PENDING"
+	JobRunning   JobStatus = "RUNNING"
+	JobCompleted JobStatus = "COMPLETED"
+	JobFailed    JobStatus = "FAILED"
+	JobExpired   JobStatus = "EXPIRED" // Relatório removido ao fim do prazo de retenção
+)
+
+// Erros da geração de relatórios
+var (
+	ErrJobNotFound       = errors.New("job de relatório não encontrado")
+	ErrUnsupportedFormat = errors.New("formato de relatório não suportado")
+	ErrInvalidReport     = errors.New("dados de relatório inválidos")
+	ErrQueueFull         = errors.New("fila de geração de relatórios cheia")
+	ErrReportNotReady    = errors.New("relatório ainda não gerado")
+	ErrReportExpired     = errors.New("relatório expirado pelo prazo de retenção do mercado")
+	ErrObjectNotFound    = errors.New("objeto não encontrado no armazenamento")
+)
+
+// RetentionPolicy define por quanto tempo um relatório é mantido e a validade das URLs pré-assinadas
+type RetentionPolicy struct {
+	Retention time.Duration `json:"retention"`
+	URLExpiry time.Duration `json:"urlExpiry"`
+}
+
+// urlExpiry retorna a validade de uma nova URL, sem ultrapassar o fim da retenção
+func (p RetentionPolicy) urlExpiry(now, retainUntil time.Time) time.Duration {
+	expiry := p.URLExpiry
+	if remaining := retainUntil.Sub(now); remaining < expiry {
+		expiry = remaining
+	}
+	return expiry
+}
+
+// DefaultRetentionPolicies retorna as políticas por mercado, alinhadas com a retenção de dados
+// dos metadados de compliance (Angola/Moçambique 10 anos, Brasil 5, EUA 6, UE 2, global 3)
+func DefaultRetentionPolicies() map[string]RetentionPolicy {
+	const day = 24 * time.Hour
+	return map[string]RetentionPolicy{
+		"angola":     {Retention: 3650 * day, URLExpiry: 15 * time.Minute},
+		"brazil":     {Retention: 1825 * day, URLExpiry: time.Hour},
+		"eu":         {Retention: 730 * day, URLExpiry: 15 * time.Minute},
+		"usa":        {Retention: 2190 * day, URLExpiry: time.Hour},
+		"mozambique": {Retention: 3650 * day, URLExpiry: 15 * time.Minute},
+		"global":     {Retention: 1095 * day, URLExpiry: time.Hour},
+	}
+}
+
+// ReportRecord representa um registro de crédito apresentado no relatório
+type ReportRecord struct {
+	Tipo           string    `json:"tipo"`
+	Origem         string    `json:"origem"`
+	Fonte          string    `json:"fonte"`
+	Valor          float64   `json:"valor"`
+	DataOcorrencia time.Time `json:"dataOcorrencia"`
+}
+
+// ReportData contém os dados de uma consulta a apresentar no relatório detalhado
+// O documento do cliente deve chegar já mascarado
+type ReportData struct {
+	ConsultaID    string         `json:"consultaId"`
+	TenantID      string         `json:"tenantId"`
+	Market        string         `json:"market"`
+	TipoConsulta  string         `json:"tipoConsulta"`
+	Finalidade    string         `json:"finalidade"`
+	Documento     string         `json:"documento"`
+	NomeCliente   string         `json:"nomeCliente"`
+	DataConsulta  time.Time      `json:"dataConsulta"`
+	Score         *int           `json:"score,omitempty"`
+	FaixaRisco    string         `json:"faixaRisco,omitempty"`
+	Registros     []ReportRecord `json:"registros,omitempty"`
+	Restricoes    []ReportRecord `json:"restricoes,omitempty"`
+	Recomendacoes []string       `json:"recomendacoes,omitempty"`
+}
+
+// validate verifica os campos mínimos do relatório
+func (d ReportData) validate() error {
+	if strings.TrimSpace(d.ConsultaID) == "" {
+		return errors.New("consultaId é obrigatório")
+	}
+	if strings.TrimSpace(d.Market) == "" {
+		return errors.New("market é obrigatório")
+	}
+	return nil
+}
+
+// ReportJob representa o estado de geração de um relatório
+// Não guarda os dados da consulta, apenas os metadados do relatório gerado
+type ReportJob struct {
+	JobID        string     `json:"jobId"`
+	ConsultaID   string     `json:"consultaId"`
+	TenantID     string     `json:"tenantId,omitempty"`
+	Market       string     `json:"market"`
+	Format       Format     `json:"format"`
+	Status       JobStatus  `json:"status"`
+	ObjectKey    string     `json:"objectKey,omitempty"`
+	Size         int        `json:"size,omitempty"`
+	URL          string     `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"urlExpiresAt,omitempty"`
+	RetainUntil  *time.Time `json:"retainUntil,omitempty"`
+	Attempts     int        `json:"attempts"`
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+}
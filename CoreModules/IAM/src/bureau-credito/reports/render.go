@@ -0,0 +1,243 @@
+/**
+ * @file render.go
+ * @description Renderização dos relatórios detalhados de consulta em HTML e PDF
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Layout da página PDF (A4 em pontos)
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLineHeight   = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfMaxLineChars = 95
+)
+
+// Render gera o relatório no formato indicado
+func Render(format Format, data ReportData) ([]byte, error) {
+	switch format {
+	case FormatHTML:
+		return RenderHTML(data)
+	case FormatPDF:
+		return RenderPDF(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+var reportFuncs = template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("02/01/2006") },
+	"stamp": func(t time.Time) string { return t.UTC().Format("02/01/2006 15:04 UTC") },
+	"money": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}
+
+var reportTemplate = template.Must(template.New("relatorio").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="pt">
+<head>
+<meta charset="utf-8">
+<title>Relatório de Consulta {{.ConsultaID}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; border-bottom: 2px solid #0b3d91; padding-bottom: .3em; }
+h2 { font-size: 1.1em; margin-top: 1.5em; color: #0b3d91; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: .3em .5em; text-align: left; font-size: .9em; }
+th { background: #f0f3f8; }
+.score { font-size: 2em; font-weight: bold; }
+footer { margin-top: 2em; font-size: .8em; color: #666; }
+</style>
+</head>
+<body>
+<h1>Relatório Detalhado de Consulta ao Bureau de Crédito</h1>
+<table>
+<tr><th>Consulta</th><td>{{.ConsultaID}}</td></tr>
+<tr><th>Data da consulta</th><td>{{stamp .DataConsulta}}</td></tr>
+<tr><th>Mercado</th><td>{{.Market}}</td></tr>
+<tr><th>Tipo / Finalidade</th><td>{{.TipoConsulta}} / {{.Finalidade}}</td></tr>
+<tr><th>Cliente</th><td>{{.NomeCliente}}</td></tr>
+<tr><th>Documento</th><td>{{.Documento}}</td></tr>
+</table>
+{{if .Score}}
+<h2>Score de Crédito</h2>
+<p><span class="score">{{.Score}}</span> {{.FaixaRisco}}</p>
+{{end}}
+<h2>Restrições ({{len .Restricoes}})</h2>
+{{template "registros" .Restricoes}}
+<h2>Histórico de Crédito ({{len .Registros}})</h2>
+{{template "registros" .Registros}}
+{{if .Recomendacoes}}
+<h2>Recomendações</h2>
+<ul>{{range .Recomendacoes}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+<footer>Documento gerado pela plataforma INNOVABIZ. Uso restrito à finalidade declarada na consulta.</footer>
+</body>
+</html>
+{{define "registros"}}{{if .}}<table>
+<tr><th>Tipo</th><th>Origem</th><th>Fonte</th><th>Valor</th><th>Ocorrência</th></tr>
+{{range .}}<tr><td>{{.Tipo}}</td><td>{{.Origem}}</td><td>{{.Fonte}}</td><td>{{money .Valor}}</td><td>{{date .DataOcorrencia}}</td></tr>
+{{end}}</table>{{else}}<p>Nenhum registro.</p>{{end}}{{end}}`))
+
+// RenderHTML gera o relatório em HTML
+func RenderHTML(data ReportData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("falha ao renderizar relatório HTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF gera o relatório em PDF de texto, com fonte Helvetica e paginação A4
+func RenderPDF(data ReportData) ([]byte, error) {
+	lines := reportLines(data)
+
+	var pages [][]string
+	for len(lines) > 0 {
+		n := pdfLinesPerPage
+		if n > len(lines) {
+			n = len(lines)
+		}
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	// Objetos: 1 catálogo, 2 árvore de páginas, 3 fonte, depois página e conteúdo por página
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		fmt.Fprintf(&content, "ET\n")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+// reportLines monta as linhas de texto do relatório PDF
+func reportLines(data ReportData) []string {
+	lines := []string{
+		"RELATÓRIO DETALHADO DE CONSULTA AO BUREAU DE CRÉDITO",
+		"",
+		"Consulta: " + data.ConsultaID,
+		"Data da consulta: " + data.DataConsulta.UTC().Format("02/01/2006 15:04 UTC"),
+		"Mercado: " + data.Market,
+		"Tipo / Finalidade: " + data.TipoConsulta + " / " + data.Finalidade,
+		"Cliente: " + data.NomeCliente,
+		"Documento: " + data.Documento,
+	}
+
+	if data.Score != nil {
+		lines = append(lines, "", fmt.Sprintf("SCORE DE CRÉDITO: %d  %s", *data.Score, data.FaixaRisco))
+	}
+
+	section := func(title string, records []ReportRecord) {
+		lines = append(lines, "", fmt.Sprintf("%s (%d)", title, len(records)))
+		if len(records) == 0 {
+			lines = append(lines, "  Nenhum registro.")
+		}
+		for _, r := range records {
+			lines = append(lines, fmt.Sprintf("  %s | %s | %s | %.2f | %s",
+				r.Tipo, r.Origem, r.Fonte, r.Valor, r.DataOcorrencia.Format("02/01/2006")))
+		}
+	}
+	section("RESTRIÇÕES", data.Restricoes)
+	section("HISTÓRICO DE CRÉDITO", data.Registros)
+
+	if len(data.Recomendacoes) > 0 {
+		lines = append(lines, "", "RECOMENDAÇÕES")
+		for _, r := range data.Recomendacoes {
+			lines = append(lines, "  - "+r)
+		}
+	}
+	lines = append(lines, "", "Documento gerado pela plataforma INNOVABIZ. Uso restrito à finalidade declarada na consulta.")
+
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapLine(line, pdfMaxLineChars)...)
+	}
+	return wrapped
+}
+
+// wrapLine quebra uma linha longa em linhas de até max caracteres
+func wrapLine(line string, max int) []string {
+	runes := []rune(line)
+	if len(runes) <= max {
+		return []string{line}
+	}
+	var out []string
+	for len(runes) > max {
+		cut := max
+		for i := max; i > max/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, string(runes[:cut]))
+		runes = []rune("    " + strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	return append(out, string(runes))
+}
+
+// pdfEscape converte o texto para WinAnsi (Latin-1) e escapa os delimitadores de string PDF
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
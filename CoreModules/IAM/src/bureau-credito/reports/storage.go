@@ -0,0 +1,361 @@
+/**
+ * @file storage.go
+ * @description Armazenamento de objetos (S3/MinIO) para os relatórios de consulta
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package reports
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectStorage define o armazenamento de objetos usado pelos relatórios
+type ObjectStorage interface {
+	// PutObject grava um objeto com o tipo de conteúdo e metadados indicados
+	PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error
+
+	// PresignGetURL gera uma URL de leitura válida durante expiry
+	PresignGetURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+	// DeleteObject remove um objeto
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// storedObject é um objeto mantido pelo InMemoryObjectStorage
+type storedObject struct {
+	body        []byte
+	contentType string
+	metadata    map[string]string
+}
+
+// InMemoryObjectStorage mantém os objetos em memória, para desenvolvimento e testes
+type InMemoryObjectStorage struct {
+	bucket  string
+	objects map[string]storedObject
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryObjectStorage cria um armazenamento em memória
+func NewInMemoryObjectStorage(bucket string) *InMemoryObjectStorage {
+	return &InMemoryObjectStorage{
+		bucket:  bucket,
+		objects: make(map[string]storedObject),
+	}
+}
+
+// PutObject grava uma cópia do objeto
+func (s *InMemoryObjectStorage) PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	s.objects[key] = storedObject{body: append([]byte(nil), body...), contentType: contentType, metadata: copied}
+	return nil
+}
+
+// PresignGetURL retorna uma URL simbólica com a expiração do objeto
+func (s *InMemoryObjectStorage) PresignGetURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if _, ok := s.objects[key]; !ok {
+		return "", ErrObjectNotFound
+	}
+	return fmt.Sprintf("memory://%s/%s?expires=%d", s.bucket, key, time.Now().Add(expiry).Unix()), nil
+}
+
+// DeleteObject remove o objeto, se existir
+func (s *InMemoryObjectStorage) DeleteObject(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+// GetObject retorna o conteúdo e o tipo de um objeto gravado
+func (s *InMemoryObjectStorage) GetObject(key string) ([]byte, string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, "", ErrObjectNotFound
+	}
+	return append([]byte(nil), object.body...), object.contentType, nil
+}
+
+// Constantes da assinatura AWS Signature Version 4
+const (
+	sigV4Algorithm       = "AWS4-HMAC-SHA256"
+	sigV4Service         = "s3"
+	sigV4Terminator      = "aws4_request"
+	sigV4DateLayout      = "20060102T150405Z"
+	sigV4UnsignedBody    = "UNSIGNED-PAYLOAD"
+	s3MaxPresignExpiry   = 7 * 24 * time.Hour
+	defaultS3HTTPTimeout = 30 * time.Second
+)
+
+// S3Config representa a configuração de um bucket S3 ou MinIO
+type S3Config struct {
+	Endpoint        string        `json:"endpoint"` // ex.: https://s3.sa-east-1.amazonaws.com ou http://minio:9000
+	Region          string        `json:"region"`
+	Bucket          string        `json:"bucket"`
+	AccessKeyID     string        `json:"accessKeyId"`
+	SecretAccessKey string        `json:"-"`
+	SessionToken    string        `json:"-"`
+	PathStyle       bool          `json:"pathStyle"` // Obrigatório para MinIO
+	HTTPTimeout     time.Duration `json:"httpTimeout"`
+}
+
+// S3Storage grava os relatórios num bucket S3 compatível (AWS S3 ou MinIO) com assinatura SigV4
+type S3Storage struct {
+	config     S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Storage cria o armazenamento S3
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Region == "" {
+		return nil, errors.New("bucket e região S3 são obrigatórios")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("credenciais S3 são obrigatórias")
+	}
+	if config.HTTPTimeout <= 0 {
+		config.HTTPTimeout = defaultS3HTTPTimeout
+	}
+
+	return &S3Storage{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: config.HTTPTimeout},
+		now:        time.Now,
+	}, nil
+}
+
+// SetClock substitui o relógio usado nas assinaturas
+func (s *S3Storage) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// PutObject grava o objeto com PUT assinado
+func (s *S3Storage) PutObject(ctx context.Context, key string, body []byte, contentType string, metadata map[string]string) error {
+	headers := map[string]string{"content-type": contentType}
+	for k, v := range metadata {
+		headers["x-amz-meta-"+strings.ToLower(k)] = v
+	}
+	return s.do(ctx, http.MethodPut, key, body, headers, http.StatusOK)
+}
+
+// DeleteObject remove o objeto com DELETE assinado
+func (s *S3Storage) DeleteObject(ctx context.Context, key string) error {
+	return s.do(ctx, http.MethodDelete, key, nil, nil, http.StatusNoContent)
+}
+
+// PresignGetURL gera uma URL GET pré-assinada (autenticação por query string)
+func (s *S3Storage) PresignGetURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if expiry <= 0 {
+		return "", errors.New("expiração da URL deve ser positiva")
+	}
+	if expiry > s3MaxPresignExpiry {
+		expiry = s3MaxPresignExpiry
+	}
+
+	now := s.now().UTC()
+	host, path := s.location(key)
+	scope := s.scope(now)
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     sigV4Algorithm,
+		"X-Amz-Credential":    s.config.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          now.Format(sigV4DateLayout),
+		"X-Amz-Expires":       strconv.Itoa(int(expiry / time.Second)),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if s.config.SessionToken != "" {
+		query["X-Amz-Security-Token"] = s.config.SessionToken
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		path,
+		canonicalQuery,
+		"host:" + host + "\n",
+		"host",
+		sigV4UnsignedBody,
+	}, "\n")
+	signature := s.sign(now, scope, canonicalRequest)
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s", s.endpoint.Scheme, host, path, canonicalQuery, signature), nil
+}
+
+// do executa uma requisição assinada no cabeçalho Authorization
+func (s *S3Storage) do(ctx context.Context, method, key string, body []byte, headers map[string]string, expected int) error {
+	now := s.now().UTC()
+	host, path := s.location(key)
+	scope := s.scope(now)
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	signed := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(sigV4DateLayout),
+	}
+	if s.config.SessionToken != "" {
+		signed["x-amz-security-token"] = s.config.SessionToken
+	}
+	for k, v := range headers {
+		signed[k] = strings.TrimSpace(v)
+	}
+
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	signature := s.sign(now, scope, canonicalRequest)
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s://%s%s", s.endpoint.Scheme, host, path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name != "host" {
+			req.Header.Set(name, signed[name])
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("falha na requisição S3 %s %s: %w", method, key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodDelete {
+		return nil
+	}
+	if resp.StatusCode != expected && resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 %s %s retornou status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// location retorna o host e o caminho canónico do objeto, conforme o estilo de endereçamento
+func (s *S3Storage) location(key string) (string, string) {
+	encodedKey := awsURIEncode(strings.TrimPrefix(key, "/"), false)
+	basePath := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.config.PathStyle {
+		return s.endpoint.Host, basePath + "/" + awsURIEncode(s.config.Bucket, true) + "/" + encodedKey
+	}
+	return s.config.Bucket + "." + s.endpoint.Host, basePath + "/" + encodedKey
+}
+
+// scope retorna o escopo da credencial para a data
+func (s *S3Storage) scope(now time.Time) string {
+	return strings.Join([]string{now.Format("20060102"), s.config.Region, sigV4Service, sigV4Terminator}, "/")
+}
+
+// sign calcula a assinatura SigV4 do pedido canónico
+func (s *S3Storage) sign(now time.Time, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(sigV4DateLayout),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, sigV4Service)
+	key = hmacSHA256(key, sigV4Terminator)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// hmacSHA256 calcula o HMAC-SHA256 da mensagem
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString ordena e codifica os parâmetros de query conforme a SigV4
+func canonicalQueryString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, awsURIEncode(k, true)+"="+awsURIEncode(params[k], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode codifica em percentagem todos os bytes exceto os não reservados (RFC 3986);
+// a barra só é codificada quando encodeSlash é verdadeiro
+func awsURIEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
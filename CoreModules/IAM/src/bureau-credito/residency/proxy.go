@@ -0,0 +1,174 @@
+/**
+ * @file proxy.go
+ * @description Encaminhamento assinado das consultas para as instâncias regionais
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package residency
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"innovabiz/iam/src/bureau-credito/telemetry"
+)
+
+// Cabeçalhos das consultas encaminhadas entre regiões
+const (
+	ForwardedFromHeader = "X-Bureau-Forwarded-From"      // Região que encaminhou a consulta
+	DecisionHeader      = "X-Bureau-Residency-Decision"  // Decisão de residência da região de origem
+	SignatureHeader     = "X-Bureau-Residency-Signature" // t=<unix>,v1=<hex(hmac_sha256(segredo, t + "." + corpo))>
+)
+
+// ForwardPath é a rota das instâncias regionais que recebe as consultas encaminhadas
+const ForwardPath = "/residency/consultas"
+
+// Valores padrão do encaminhamento
+const (
+	DefaultProxyTimeout       = 30 * time.Second
+	DefaultSignatureTolerance = 5 * time.Minute
+	maxForwardedResponseBytes = 4 << 20
+)
+
+// Erros do encaminhamento
+var (
+	ErrNotProxied          = errors.New("a decisão de residência não encaminha a consulta")
+	ErrRegionalInstance    = errors.New("a instância regional recusou ou falhou a consulta")
+	ErrForwardUnauthorized = errors.New("consulta encaminhada sem assinatura válida da região de origem")
+)
+
+// forwardedFromKey é a chave do contexto com a região que encaminhou a consulta
+type forwardedFromKey struct{}
+
+// WithForwardedFrom marca o contexto de uma consulta recebida de outra região
+func WithForwardedFrom(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, forwardedFromKey{}, normalize(region))
+}
+
+// ForwardedFrom retorna a região que encaminhou a consulta do contexto, vazia se originada localmente
+func ForwardedFrom(ctx context.Context) string {
+	region, _ := ctx.Value(forwardedFromKey{}).(string)
+	return region
+}
+
+// Proxy encaminha as consultas para a instância da região do mercado do documento
+// O corpo é assinado com o segredo partilhado com a região, que verifica a origem antes de processar
+type Proxy struct {
+	router *Router
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewProxy cria o encaminhador; um timeout não positivo usa o valor padrão
+func NewProxy(router *Router, timeout time.Duration) *Proxy {
+	if timeout <= 0 {
+		timeout = DefaultProxyTimeout
+	}
+	return &Proxy{
+		router: router,
+		client: &http.Client{Timeout: timeout, Transport: telemetry.NewTransport(nil)},
+		now:    time.Now,
+	}
+}
+
+// Forward envia a consulta para a região de destino da decisão e decodifica a resposta em response
+func (p *Proxy) Forward(ctx context.Context, decision *Decision, request, response interface{}) error {
+	if decision == nil || decision.Outcome != OutcomeProxied {
+		return ErrNotProxied
+	}
+	endpoint, exists := p.router.Endpoint(decision.TargetRegion)
+	if !exists {
+		return ErrRegionUnavailable
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar a consulta encaminhada: %w", err)
+	}
+
+	url := strings.TrimSuffix(endpoint.URL, "/") + ForwardPath
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("falha ao criar a consulta encaminhada: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Tenant-ID", decision.TenantID)
+	httpReq.Header.Set(ForwardedFromHeader, p.router.LocalRegion())
+	httpReq.Header.Set(DecisionHeader, decision.ID)
+	httpReq.Header.Set(SignatureHeader, SignPayload(endpoint.Secret, p.now(), body))
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRegionalInstance, decision.TargetRegion, err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, maxForwardedResponseBytes))
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRegionalInstance, decision.TargetRegion, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %s respondeu %d", ErrRegionalInstance, decision.TargetRegion, resp.StatusCode)
+	}
+	if err := json.Unmarshal(payload, response); err != nil {
+		return fmt.Errorf("%w: resposta inválida de %s: %v", ErrRegionalInstance, decision.TargetRegion, err)
+	}
+	return nil
+}
+
+// VerifyForwarded valida a assinatura de uma consulta recebida de outra região
+// com o segredo partilhado com essa região
+func (r *Router) VerifyForwarded(from, header string, body []byte, now time.Time) error {
+	endpoint, exists := r.config.Regions[normalize(from)]
+	if !exists || endpoint.Secret == "" {
+		return ErrForwardUnauthorized
+	}
+	if err := VerifySignature(endpoint.Secret, header, body, DefaultSignatureTolerance, now); err != nil {
+		return fmt.Errorf("%w: %v", ErrForwardUnauthorized, err)
+	}
+	return nil
+}
+
+// SignPayload calcula o cabeçalho de assinatura do corpo encaminhado
+func SignPayload(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// VerifySignature valida o cabeçalho de assinatura, rejeitando corpos assinados há mais de tolerance
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	for _, part := range strings.Split(header, ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(part), "t="); ok {
+			timestamp = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("assinatura sem timestamp válido")
+	}
+	signedAt := time.Unix(unix, 0)
+	if tolerance > 0 && (now.Sub(signedAt) > tolerance || signedAt.Sub(now) > tolerance) {
+		return fmt.Errorf("assinatura fora da janela de tolerância")
+	}
+	if !hmac.Equal([]byte(SignPayload(secret, signedAt, body)), []byte(strings.TrimSpace(header))) {
+		return fmt.Errorf("assinatura inválida")
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+/**
+ * @file router.go
+ * @description Roteamento das consultas entre mercados com garantias de residência dos dados
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package residency
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Resultado de uma decisão de residência
+const (
+	OutcomeLocal   = "local"   // A consulta é processada nesta instância
+	OutcomeProxied = "proxied" // A consulta é encaminhada para a instância regional do mercado
+	OutcomeBlocked = "blocked" // A consulta é recusada para não violar a residência dos dados
+)
+
+// Motivos de uma decisão de residência
+const (
+	ReasonHomeRegion         = "HOME_REGION"          // Esta instância é a região do mercado
+	ReasonProcessingAllowed  = "PROCESSING_ALLOWED"   // O mercado autoriza esta região a processar os seus dados
+	ReasonNoPolicy           = "NO_POLICY"            // O mercado é desconhecido ou não tem política de residência configurada
+	ReasonRegionalInstance   = "REGIONAL_INSTANCE"    // Encaminhada para a instância da região do mercado
+	ReasonTransferProhibited = "TRANSFER_PROHIBITED"  // O mercado proíbe a transferência dos resultados para esta região
+	ReasonNoRegionalInstance = "NO_REGIONAL_INSTANCE" // Não há instância configurada na região do mercado
+	ReasonForwardingLoop     = "FORWARDING_LOOP"      // A consulta já foi encaminhada por outra região
+)
+
+// Origem do mercado do documento
+const (
+	HomeSourceDocumentType  = "document_type"
+	HomeSourceNationality   = "nationality"
+	HomeSourceRequestMarket = "request_market"
+)
+
+// Erros do roteamento de residência
+var (
+	ErrTransferProhibited = errors.New("o mercado do documento proíbe a transferência dos dados para esta região")
+	ErrRegionUnavailable  = errors.New("nenhuma instância configurada na região do mercado do documento")
+	ErrForwardingLoop     = errors.New("consulta encaminhada por outra região não pode ser reencaminhada")
+)
+
+// MarketPolicy define onde os dados de um mercado podem ser processados e para onde podem ser transferidos
+type MarketPolicy struct {
+	// Region é a região da implantação que processa os dados do mercado
+	Region string `json:"region"`
+
+	// ProcessingRegions lista outras regiões autorizadas a processar os dados do mercado
+	ProcessingRegions []string `json:"processingRegions,omitempty"`
+
+	// RestrictTransfers limita as regiões que podem receber os resultados de uma consulta encaminhada
+	RestrictTransfers bool `json:"restrictTransfers,omitempty"`
+
+	// TransferRegions lista as regiões que podem receber os resultados quando RestrictTransfers está ativo
+	TransferRegions []string `json:"transferRegions,omitempty"`
+}
+
+// processes indica se a região pode processar os dados do mercado
+func (p MarketPolicy) processes(region string) bool {
+	return p.Region == region || contains(p.ProcessingRegions, region)
+}
+
+// transfersTo indica se os resultados podem ser transferidos para a região
+func (p MarketPolicy) transfersTo(region string) bool {
+	return !p.RestrictTransfers || contains(p.TransferRegions, region)
+}
+
+// RegionEndpoint define a instância do Bureau de Crédito de uma região
+type RegionEndpoint struct {
+	// URL é o endereço base da API da instância regional
+	URL string `json:"url"`
+
+	// Secret assina as consultas encaminhadas para a instância
+	Secret string `json:"secret"`
+}
+
+// Config define o roteamento de residência
+type Config struct {
+	// LocalRegion é a região desta instância
+	LocalRegion string `json:"localRegion"`
+
+	// Markets contém a política de residência de cada mercado
+	Markets map[string]MarketPolicy `json:"markets"`
+
+	// Regions contém as instâncias das outras regiões
+	Regions map[string]RegionEndpoint `json:"regions,omitempty"`
+
+	// DocumentMarkets associa tipos de documento ao mercado emissor (ex.: CPF → brazil)
+	DocumentMarkets map[string]string `json:"documentMarkets,omitempty"`
+}
+
+// Subject descreve a consulta e o documento consultado
+type Subject struct {
+	ConsultaID    string
+	TenantID      string
+	Market        string // Mercado indicado na consulta
+	DocumentType  string
+	Nationality   string
+	ForwardedFrom string // Região que encaminhou a consulta, vazia para consultas originadas localmente
+}
+
+// Decision é o registo de auditoria de uma decisão de residência
+type Decision struct {
+	ID            string    `json:"id"`
+	ConsultaID    string    `json:"consultaId,omitempty"`
+	TenantID      string    `json:"tenantId"`
+	RequestMarket string    `json:"requestMarket,omitempty"`
+	HomeMarket    string    `json:"homeMarket,omitempty"`
+	HomeSource    string    `json:"homeSource,omitempty"`
+	LocalRegion   string    `json:"localRegion"`
+	HomeRegion    string    `json:"homeRegion,omitempty"`
+	TargetRegion  string    `json:"targetRegion,omitempty"` // Região que processa a consulta
+	ForwardedFrom string    `json:"forwardedFrom,omitempty"`
+	CrossBorder   bool      `json:"crossBorder"`
+	Outcome       string    `json:"outcome"`
+	Reason        string    `json:"reason"`
+	DecidedAt     time.Time `json:"decidedAt"`
+}
+
+// Router determina o mercado do documento consultado e decide se a consulta é processada
+// localmente, encaminhada para a instância regional do mercado ou recusada
+// As decisões que envolvem mais de uma região são sempre registadas na auditoria
+type Router struct {
+	config  Config
+	audit   AuditLog
+	metrics *Metrics
+	now     func() time.Time
+}
+
+// NewRouter cria o roteador de residência com auditoria em memória
+func NewRouter(config Config) *Router {
+	config.LocalRegion = normalize(config.LocalRegion)
+	markets := make(map[string]MarketPolicy, len(config.Markets))
+	for market, policy := range config.Markets {
+		policy.Region = normalize(policy.Region)
+		markets[normalize(market)] = policy
+	}
+	config.Markets = markets
+	regions := make(map[string]RegionEndpoint, len(config.Regions))
+	for region, endpoint := range config.Regions {
+		regions[normalize(region)] = endpoint
+	}
+	config.Regions = regions
+	documents := make(map[string]string, len(config.DocumentMarkets))
+	for documentType, market := range config.DocumentMarkets {
+		documents[normalize(documentType)] = normalize(market)
+	}
+	config.DocumentMarkets = documents
+
+	return &Router{
+		config: config,
+		audit:  NewMemoryAuditLog(DefaultAuditEntries),
+		now:    time.Now,
+	}
+}
+
+// SetClock substitui o relógio usado nas decisões
+func (r *Router) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// SetMetrics define as métricas Prometheus das decisões de residência
+func (r *Router) SetMetrics(metrics *Metrics) {
+	r.metrics = metrics
+}
+
+// SetAuditLog substitui o registo de auditoria das decisões
+func (r *Router) SetAuditLog(audit AuditLog) {
+	r.audit = audit
+}
+
+// LocalRegion retorna a região desta instância
+func (r *Router) LocalRegion() string {
+	return r.config.LocalRegion
+}
+
+// Endpoint retorna a instância configurada da região
+func (r *Router) Endpoint(region string) (RegionEndpoint, bool) {
+	endpoint, exists := r.config.Regions[normalize(region)]
+	return endpoint, exists && endpoint.URL != ""
+}
+
+// Route decide onde a consulta é processada
+// Quando a consulta é recusada a decisão é retornada com o erro correspondente
+func (r *Router) Route(ctx context.Context, subject Subject) (*Decision, error) {
+	homeMarket, homeSource := r.homeMarket(subject)
+
+	decision := &Decision{
+		ID:            uuid.New().String(),
+		ConsultaID:    subject.ConsultaID,
+		TenantID:      subject.TenantID,
+		RequestMarket: normalize(subject.Market),
+		HomeMarket:    homeMarket,
+		HomeSource:    homeSource,
+		LocalRegion:   r.config.LocalRegion,
+		ForwardedFrom: normalize(subject.ForwardedFrom),
+		DecidedAt:     r.now(),
+	}
+	decision.CrossBorder = decision.ForwardedFrom != ""
+
+	var err error
+	policy, exists := r.config.Markets[homeMarket]
+	switch {
+	case !exists:
+		decision.Outcome, decision.Reason = OutcomeLocal, ReasonNoPolicy
+		decision.TargetRegion = r.config.LocalRegion
+	case policy.processes(r.config.LocalRegion):
+		decision.HomeRegion = policy.Region
+		decision.TargetRegion = r.config.LocalRegion
+		decision.Outcome, decision.Reason = OutcomeLocal, ReasonHomeRegion
+		if policy.Region != r.config.LocalRegion {
+			decision.Reason = ReasonProcessingAllowed
+			decision.CrossBorder = true
+		}
+	default:
+		decision.HomeRegion = policy.Region
+		decision.CrossBorder = true
+		decision.Outcome = OutcomeBlocked
+		switch {
+		case decision.ForwardedFrom != "":
+			decision.Reason, err = ReasonForwardingLoop, ErrForwardingLoop
+		case !policy.transfersTo(r.config.LocalRegion):
+			decision.Reason, err = ReasonTransferProhibited, ErrTransferProhibited
+		default:
+			if _, available := r.Endpoint(policy.Region); !available {
+				decision.Reason, err = ReasonNoRegionalInstance, ErrRegionUnavailable
+				break
+			}
+			decision.Outcome, decision.Reason = OutcomeProxied, ReasonRegionalInstance
+			decision.TargetRegion = policy.Region
+		}
+	}
+
+	r.metrics.observeDecision(decision)
+	if decision.CrossBorder && r.audit != nil {
+		if auditErr := r.audit.Record(ctx, *decision); auditErr != nil {
+			log.Warn().Err(auditErr).
+				Str("decision_id", decision.ID).
+				Str("tenant_id", decision.TenantID).
+				Msg("Falha ao registar decisão de residência na auditoria")
+		}
+	}
+	return decision, err
+}
+
+// Decisions retorna as decisões entre regiões mais recentes do tenant registadas na auditoria
+func (r *Router) Decisions(ctx context.Context, tenantID string, limit int) ([]Decision, error) {
+	if r.audit == nil {
+		return nil, nil
+	}
+	return r.audit.List(ctx, tenantID, limit)
+}
+
+// homeMarket determina o mercado do documento: pelo tipo de documento, pela nacionalidade
+// quando corresponde a um mercado com política e, por fim, pelo mercado indicado na consulta
+func (r *Router) homeMarket(subject Subject) (string, string) {
+	if market, exists := r.config.DocumentMarkets[normalize(subject.DocumentType)]; exists && market != "" {
+		return market, HomeSourceDocumentType
+	}
+	if nationality := normalize(subject.Nationality); nationality != "" {
+		if _, exists := r.config.Markets[nationality]; exists {
+			return nationality, HomeSourceNationality
+		}
+	}
+	if market := normalize(subject.Market); market != "" {
+		return market, HomeSourceRequestMarket
+	}
+	return "", ""
+}
+
+// normalize compara mercados, regiões e tipos de documento sem distinguir maiúsculas
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// contains indica se a região está na lista
+func contains(regions []string, region string) bool {
+	for _, candidate := range regions {
+		if normalize(candidate) == region {
+			return true
+		}
+	}
+	return false
+}
+
+// Metrics contém as métricas Prometheus do roteamento de residência
+type Metrics struct {
+	decisionsCounter *prometheus.CounterVec
+}
+
+// NewMetrics cria e regista as métricas do roteamento de residência
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		decisionsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_residency_decisions_total",
+				Help: "Número total de decisões de residência por mercado do documento, região de destino e resultado",
+			},
+			[]string{"home_market", "target_region", "outcome", "reason"},
+		),
+	}
+
+	registry.MustRegister(m.decisionsCounter)
+	return m
+}
+
+// observeDecision regista o resultado de uma decisão de residência
+// Os mercados sem política são agregados para não criar séries a partir dos dados das consultas
+func (m *Metrics) observeDecision(decision *Decision) {
+	if m == nil {
+		return
+	}
+	market := decision.HomeMarket
+	if decision.Reason == ReasonNoPolicy {
+		market = "other"
+	}
+	m.decisionsCounter.WithLabelValues(market, decision.TargetRegion, decision.Outcome, decision.Reason).Inc()
+}
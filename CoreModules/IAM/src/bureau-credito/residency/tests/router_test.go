@@ -0,0 +1,215 @@
+/**
+ * @file router_test.go
+ * @description Testes do roteamento de residência das consultas entre mercados
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/residency"
+)
+
+func residencyConfig(localRegion string) residency.Config {
+	return residency.Config{
+		LocalRegion: localRegion,
+		Markets: map[string]residency.MarketPolicy{
+			"angola": {Region: "af-south"},
+			"brazil": {Region: "sa-east", ProcessingRegions: []string{"us-east"}},
+			"portugal": {
+				Region:            "eu-west",
+				RestrictTransfers: true,
+				TransferRegions:   []string{"eu-central"},
+			},
+		},
+		Regions: map[string]residency.RegionEndpoint{
+			"sa-east": {URL: "https://bureau.sa-east.innovabiz.local", Secret: "segredo-sa"},
+		},
+		DocumentMarkets: map[string]string{"CPF": "brazil", "BI": "angola", "CC": "portugal"},
+	}
+}
+
+func TestRouteProcessesLocallyInHomeRegionWithoutAudit(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("af-south"))
+
+	decision, err := router.Route(context.Background(), residency.Subject{
+		ConsultaID:   "consulta-1",
+		TenantID:     "tenant-1",
+		Market:       "angola",
+		DocumentType: "BI",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, residency.OutcomeLocal, decision.Outcome)
+	assert.Equal(t, residency.ReasonHomeRegion, decision.Reason)
+	assert.Equal(t, "angola", decision.HomeMarket)
+	assert.Equal(t, residency.HomeSourceDocumentType, decision.HomeSource)
+	assert.False(t, decision.CrossBorder)
+
+	decisions, err := router.Decisions(context.Background(), "tenant-1", 0)
+	require.NoError(t, err)
+	assert.Empty(t, decisions, "decisões na própria região não são auditadas")
+}
+
+func TestRouteProxiesToHomeRegionByDocumentType(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("af-south"))
+
+	// O documento brasileiro prevalece sobre o mercado indicado pelo tenant
+	decision, err := router.Route(context.Background(), residency.Subject{
+		ConsultaID:   "consulta-2",
+		TenantID:     "tenant-1",
+		Market:       "angola",
+		DocumentType: "cpf",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, residency.OutcomeProxied, decision.Outcome)
+	assert.Equal(t, residency.ReasonRegionalInstance, decision.Reason)
+	assert.Equal(t, "brazil", decision.HomeMarket)
+	assert.Equal(t, "sa-east", decision.TargetRegion)
+	assert.True(t, decision.CrossBorder)
+
+	decisions, err := router.Decisions(context.Background(), "tenant-1", 0)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, decision.ID, decisions[0].ID)
+}
+
+func TestRouteAllowsAuthorizedProcessingRegion(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("us-east"))
+
+	decision, err := router.Route(context.Background(), residency.Subject{TenantID: "tenant-1", Nationality: "Brazil"})
+	require.NoError(t, err)
+	assert.Equal(t, residency.OutcomeLocal, decision.Outcome)
+	assert.Equal(t, residency.ReasonProcessingAllowed, decision.Reason)
+	assert.Equal(t, residency.HomeSourceNationality, decision.HomeSource)
+	assert.True(t, decision.CrossBorder, "processamento fora da região do mercado é auditado")
+}
+
+func TestRouteBlocksProhibitedTransfers(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("af-south"))
+
+	decision, err := router.Route(context.Background(), residency.Subject{
+		ConsultaID:   "consulta-3",
+		TenantID:     "tenant-1",
+		DocumentType: "CC",
+	})
+	assert.ErrorIs(t, err, residency.ErrTransferProhibited)
+	require.NotNil(t, decision)
+	assert.Equal(t, residency.OutcomeBlocked, decision.Outcome)
+	assert.Equal(t, residency.ReasonTransferProhibited, decision.Reason)
+	assert.Equal(t, "eu-west", decision.HomeRegion)
+
+	decisions, err := router.Decisions(context.Background(), "tenant-1", 0)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, residency.OutcomeBlocked, decisions[0].Outcome)
+}
+
+func TestRouteBlocksWhenRegionalInstanceIsMissingOrForwardingLoops(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("sa-east"))
+
+	_, err := router.Route(context.Background(), residency.Subject{TenantID: "tenant-1", DocumentType: "BI"})
+	assert.ErrorIs(t, err, residency.ErrRegionUnavailable)
+
+	decision, err := router.Route(context.Background(), residency.Subject{
+		TenantID:      "tenant-1",
+		DocumentType:  "BI",
+		ForwardedFrom: "af-south",
+	})
+	assert.ErrorIs(t, err, residency.ErrForwardingLoop)
+	assert.Equal(t, residency.ReasonForwardingLoop, decision.Reason)
+}
+
+func TestRouteWithoutPolicyProcessesLocally(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("af-south"))
+
+	decision, err := router.Route(context.Background(), residency.Subject{TenantID: "tenant-1", Market: "mozambique"})
+	require.NoError(t, err)
+	assert.Equal(t, residency.OutcomeLocal, decision.Outcome)
+	assert.Equal(t, residency.ReasonNoPolicy, decision.Reason)
+
+	decision, err = router.Route(context.Background(), residency.Subject{TenantID: "tenant-1"})
+	require.NoError(t, err)
+	assert.Equal(t, residency.ReasonNoPolicy, decision.Reason)
+	assert.Empty(t, decision.HomeMarket)
+}
+
+func TestProxyForwardsSignedConsultaToRegionalInstance(t *testing.T) {
+	type consulta struct {
+		RequestID string `json:"requestId"`
+	}
+
+	regional := residency.NewRouter(residency.Config{
+		LocalRegion: "sa-east",
+		Markets:     map[string]residency.MarketPolicy{"brazil": {Region: "sa-east"}},
+		Regions:     map[string]residency.RegionEndpoint{"af-south": {Secret: "segredo-sa"}},
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, residency.ForwardPath, r.URL.Path)
+		assert.Equal(t, "tenant-1", r.Header.Get("X-Tenant-ID"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		from := r.Header.Get(residency.ForwardedFromHeader)
+		assert.Equal(t, "af-south", from)
+		if err := regional.VerifyForwarded(from, r.Header.Get(residency.SignatureHeader), body, time.Now()); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var request consulta
+		require.NoError(t, json.Unmarshal(body, &request))
+		json.NewEncoder(w).Encode(map[string]string{"requestId": request.RequestID, "status": "COMPLETED"})
+	}))
+	defer server.Close()
+
+	config := residencyConfig("af-south")
+	config.Regions["sa-east"] = residency.RegionEndpoint{URL: server.URL, Secret: "segredo-sa"}
+	router := residency.NewRouter(config)
+
+	decision, err := router.Route(context.Background(), residency.Subject{TenantID: "tenant-1", DocumentType: "CPF"})
+	require.NoError(t, err)
+
+	var response struct {
+		RequestID string `json:"requestId"`
+		Status    string `json:"status"`
+	}
+	proxy := residency.NewProxy(router, time.Second)
+	require.NoError(t, proxy.Forward(context.Background(), decision, consulta{RequestID: "consulta-4"}, &response))
+	assert.Equal(t, "consulta-4", response.RequestID)
+	assert.Equal(t, "COMPLETED", response.Status)
+
+	// Um segredo diferente é recusado pela instância regional
+	config.Regions["sa-east"] = residency.RegionEndpoint{URL: server.URL, Secret: "outro-segredo"}
+	router = residency.NewRouter(config)
+	decision, err = router.Route(context.Background(), residency.Subject{TenantID: "tenant-1", DocumentType: "CPF"})
+	require.NoError(t, err)
+	err = residency.NewProxy(router, time.Second).Forward(context.Background(), decision, consulta{RequestID: "consulta-5"}, &response)
+	assert.ErrorIs(t, err, residency.ErrRegionalInstance)
+}
+
+func TestVerifyForwardedRejectsUnknownRegionAndStaleSignature(t *testing.T) {
+	router := residency.NewRouter(residencyConfig("af-south"))
+	body := []byte(`{"requestId":"consulta-6"}`)
+	now := time.Now()
+
+	err := router.VerifyForwarded("eu-west", residency.SignPayload("segredo-sa", now, body), body, now)
+	assert.ErrorIs(t, err, residency.ErrForwardUnauthorized)
+
+	err = router.VerifyForwarded("sa-east", residency.SignPayload("segredo-sa", now.Add(-time.Hour), body), body, now)
+	assert.ErrorIs(t, err, residency.ErrForwardUnauthorized)
+
+	assert.NoError(t, router.VerifyForwarded("SA-EAST", residency.SignPayload("segredo-sa", now, body), body, now))
+}
@@ -0,0 +1,71 @@
+/**
+ * @file audit.go
+ * @description Registo de auditoria das decisões de roteamento por custo
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package routing
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultAuditEntries limita as decisões mantidas pelo registo em memória
+const DefaultAuditEntries = 1000
+
+// AuditLog guarda as decisões de roteamento
+type AuditLog interface {
+	// Record regista uma decisão
+	Record(ctx context.Context, decision Decision) error
+
+	// List retorna as decisões mais recentes do tenant, da mais recente para a mais antiga
+	List(ctx context.Context, tenantID string, limit int) ([]Decision, error)
+}
+
+// MemoryAuditLog mantém em memória as decisões mais recentes, descartando as mais antigas
+type MemoryAuditLog struct {
+	maxEntries int
+
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// NewMemoryAuditLog cria o registo em memória; um limite não positivo usa o valor padrão
+func NewMemoryAuditLog(maxEntries int) *MemoryAuditLog {
+	if maxEntries <= 0 {
+		maxEntries = DefaultAuditEntries
+	}
+	return &MemoryAuditLog{maxEntries: maxEntries}
+}
+
+// Record regista uma decisão
+func (l *MemoryAuditLog) Record(ctx context.Context, decision Decision) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.decisions) >= l.maxEntries {
+		l.decisions = append(l.decisions[:0], l.decisions[len(l.decisions)-l.maxEntries+1:]...)
+	}
+	l.decisions = append(l.decisions, decision)
+	return nil
+}
+
+// List retorna as decisões mais recentes do tenant; um limite não positivo retorna todas
+func (l *MemoryAuditLog) List(ctx context.Context, tenantID string, limit int) ([]Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var decisions []Decision
+	for i := len(l.decisions) - 1; i >= 0; i-- {
+		if l.decisions[i].TenantID != tenantID {
+			continue
+		}
+		decisions = append(decisions, l.decisions[i])
+		if limit > 0 && len(decisions) == limit {
+			break
+		}
+	}
+	return decisions, nil
+}
@@ -0,0 +1,430 @@
+/**
+ * @file router.go
+ * @description Roteamento por custo das consultas entre provedores de crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Resultado de uma decisão de roteamento
+const (
+	OutcomeRouted         = "routed"
+	OutcomeNoProvider     = "no_provider"
+	OutcomeBudgetExceeded = "budget_exceeded"
+)
+
+// Motivos da avaliação de cada provedor candidato
+const (
+	ReasonSelected      = "SELECTED"       // Provedor mais barato que satisfaz os requisitos
+	ReasonFallback      = "FALLBACK"       // Elegível, consultado se os anteriores falharem
+	ReasonNoRateCard    = "NO_RATE_CARD"   // Sem tarifa para o tipo de consulta
+	ReasonMissingFields = "MISSING_FIELDS" // Não devolve todos os campos exigidos
+	ReasonOverBudget    = "OVER_BUDGET"    // A tarifa excede o orçamento restante do tenant
+)
+
+// Erros do roteamento
+var (
+	ErrTipoRequired       = errors.New("tipo de consulta é obrigatório para o roteamento por custo")
+	ErrNoEligibleProvider = errors.New("nenhum provedor satisfaz o tipo de consulta e os campos exigidos")
+	ErrBudgetExceeded     = errors.New("orçamento mensal de consultas do tenant esgotado")
+)
+
+// ProviderOffer define as tarifas e os campos de dados de um provedor
+type ProviderOffer struct {
+	// Fees é a tarifa por consulta, por tipo de consulta; tipos ausentes não são suportados
+	Fees map[string]float64 `json:"fees"`
+
+	// Fields lista os campos de dados devolvidos pelo provedor
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Config define o roteamento por custo
+type Config struct {
+	// Currency é a moeda das tarifas e dos orçamentos
+	Currency string `json:"currency"`
+
+	// Providers contém a oferta de cada provedor
+	Providers map[string]ProviderOffer `json:"providers"`
+
+	// DefaultTipo é usado nas consultas sem tipo
+	DefaultTipo string `json:"defaultTipo,omitempty"`
+
+	// DefaultMonthlyBudget limita o gasto mensal dos tenants sem orçamento próprio; zero não limita
+	DefaultMonthlyBudget float64 `json:"defaultMonthlyBudget,omitempty"`
+
+	// TenantBudgets sobrepõe DefaultMonthlyBudget por tenant
+	TenantBudgets map[string]float64 `json:"tenantBudgets,omitempty"`
+}
+
+// budgetFor retorna o orçamento mensal do tenant
+func (c Config) budgetFor(tenantID string) float64 {
+	if budget, exists := c.TenantBudgets[tenantID]; exists {
+		return budget
+	}
+	return c.DefaultMonthlyBudget
+}
+
+// Requirement descreve a consulta a rotear
+type Requirement struct {
+	ConsultaID string
+	TenantID   string
+	Tipo       string
+	Fields     []string // Campos de dados exigidos na resposta
+
+	// Candidates é a ordem de preferência dos provedores, usada para desempatar tarifas iguais
+	Candidates []string
+
+	// Degraded lista os provedores consultados apenas depois de todos os restantes (ex.: violação de SLA)
+	Degraded []string
+}
+
+// CandidateEvaluation é a avaliação de um provedor candidato numa decisão
+type CandidateEvaluation struct {
+	Provider      string   `json:"provider"`
+	Fee           float64  `json:"fee,omitempty"`
+	Degraded      bool     `json:"degraded,omitempty"`
+	Reason        string   `json:"reason"`
+	MissingFields []string `json:"missingFields,omitempty"`
+}
+
+// Decision é o registo de auditoria de uma decisão de roteamento
+type Decision struct {
+	ID          string                `json:"id"`
+	ConsultaID  string                `json:"consultaId,omitempty"`
+	TenantID    string                `json:"tenantId"`
+	Tipo        string                `json:"tipo"`
+	Fields      []string              `json:"fields,omitempty"`
+	Outcome     string                `json:"outcome"`
+	Selected    string                `json:"selected,omitempty"`
+	Fee         float64               `json:"fee"`
+	Currency    string                `json:"currency,omitempty"`
+	Providers   []string              `json:"providers,omitempty"` // Elegíveis pela ordem de consulta; o primeiro é o selecionado
+	Candidates  []CandidateEvaluation `json:"candidates"`
+	BudgetLimit float64               `json:"budgetLimit,omitempty"`
+	BudgetSpent float64               `json:"budgetSpent"` // Gasto do tenant no mês no momento da decisão
+	DecidedAt   time.Time             `json:"decidedAt"`
+}
+
+// Spend é o gasto de um tenant no mês corrente
+type Spend struct {
+	TenantID   string             `json:"tenantId"`
+	Period     string             `json:"period"` // Mês civil em UTC (AAAA-MM)
+	Currency   string             `json:"currency,omitempty"`
+	Limit      float64            `json:"limit,omitempty"`
+	Spent      float64            `json:"spent"`
+	Remaining  *float64           `json:"remaining,omitempty"` // Ausente quando o tenant não tem orçamento
+	Consultas  int                `json:"consultas"`
+	ByProvider map[string]float64 `json:"byProvider,omitempty"`
+}
+
+// tenantSpend acumula o gasto de um tenant num mês
+type tenantSpend struct {
+	period     string
+	spent      float64
+	consultas  int
+	byProvider map[string]float64
+}
+
+// Router seleciona o provedor mais barato que suporta o tipo de consulta e devolve os campos
+// exigidos, respeitando o orçamento mensal de cada tenant, e regista cada decisão na auditoria
+type Router struct {
+	config  Config
+	audit   AuditLog
+	metrics *Metrics
+	now     func() time.Time
+
+	mu    sync.Mutex
+	spend map[string]*tenantSpend
+}
+
+// NewRouter cria o roteador por custo com auditoria em memória
+func NewRouter(config Config) *Router {
+	return &Router{
+		config: config,
+		audit:  NewMemoryAuditLog(DefaultAuditEntries),
+		now:    time.Now,
+		spend:  make(map[string]*tenantSpend),
+	}
+}
+
+// SetClock substitui o relógio usado nas decisões e no período dos orçamentos
+func (r *Router) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// SetMetrics define as métricas Prometheus do roteamento e do gasto
+func (r *Router) SetMetrics(metrics *Metrics) {
+	r.metrics = metrics
+}
+
+// SetAuditLog substitui o registo de auditoria das decisões
+func (r *Router) SetAuditLog(audit AuditLog) {
+	r.audit = audit
+}
+
+// Route avalia os candidatos e ordena os elegíveis por tarifa crescente, com os degradados no fim
+// A decisão é sempre registada na auditoria; quando nenhum provedor é elegível é retornada com o erro
+func (r *Router) Route(ctx context.Context, req Requirement) (*Decision, error) {
+	if req.Tipo == "" {
+		req.Tipo = r.config.DefaultTipo
+	}
+	if req.Tipo == "" {
+		return nil, ErrTipoRequired
+	}
+
+	now := r.now()
+	degraded := make(map[string]bool, len(req.Degraded))
+	for _, provider := range req.Degraded {
+		degraded[provider] = true
+	}
+
+	decision := &Decision{
+		ID:          uuid.New().String(),
+		ConsultaID:  req.ConsultaID,
+		TenantID:    req.TenantID,
+		Tipo:        req.Tipo,
+		Fields:      req.Fields,
+		Currency:    r.config.Currency,
+		BudgetLimit: r.config.budgetFor(req.TenantID),
+		DecidedAt:   now,
+	}
+
+	r.mu.Lock()
+	decision.BudgetSpent = r.tenantSpend(req.TenantID, now).spent
+	r.mu.Unlock()
+
+	seen := make(map[string]bool, len(req.Candidates))
+	var eligible []int
+	for _, provider := range req.Candidates {
+		if seen[provider] {
+			continue
+		}
+		seen[provider] = true
+
+		evaluation := CandidateEvaluation{Provider: provider, Degraded: degraded[provider]}
+		offer, exists := r.config.Providers[provider]
+		fee, supported := offer.Fees[req.Tipo]
+		switch {
+		case !exists || !supported:
+			evaluation.Reason = ReasonNoRateCard
+		default:
+			evaluation.Fee = fee
+			evaluation.MissingFields = missingFields(offer.Fields, req.Fields)
+			switch {
+			case len(evaluation.MissingFields) > 0:
+				evaluation.Reason = ReasonMissingFields
+			case decision.BudgetLimit > 0 && decision.BudgetSpent+fee > decision.BudgetLimit:
+				evaluation.Reason = ReasonOverBudget
+			default:
+				eligible = append(eligible, len(decision.Candidates))
+			}
+		}
+		decision.Candidates = append(decision.Candidates, evaluation)
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := decision.Candidates[eligible[i]], decision.Candidates[eligible[j]]
+		if a.Degraded != b.Degraded {
+			return !a.Degraded
+		}
+		return a.Fee < b.Fee
+	})
+
+	var err error
+	switch {
+	case len(eligible) > 0:
+		decision.Outcome = OutcomeRouted
+		for i, index := range eligible {
+			candidate := &decision.Candidates[index]
+			candidate.Reason = ReasonFallback
+			if i == 0 {
+				candidate.Reason = ReasonSelected
+				decision.Selected = candidate.Provider
+				decision.Fee = candidate.Fee
+			}
+			decision.Providers = append(decision.Providers, candidate.Provider)
+		}
+	case hasReason(decision.Candidates, ReasonOverBudget):
+		decision.Outcome = OutcomeBudgetExceeded
+		err = ErrBudgetExceeded
+	default:
+		decision.Outcome = OutcomeNoProvider
+		err = ErrNoEligibleProvider
+	}
+
+	r.metrics.observeDecision(decision)
+	if r.audit != nil {
+		if auditErr := r.audit.Record(ctx, *decision); auditErr != nil {
+			log.Warn().Err(auditErr).
+				Str("decision_id", decision.ID).
+				Str("tenant_id", decision.TenantID).
+				Msg("Falha ao registar decisão de roteamento na auditoria")
+		}
+	}
+	return decision, err
+}
+
+// Charge regista no gasto do tenant a tarifa de uma consulta concluída no provedor e retorna a tarifa
+// O orçamento é verificado no roteamento: consultas concorrentes podem excedê-lo ligeiramente
+func (r *Router) Charge(tenantID, tipo, provider string) float64 {
+	if tipo == "" {
+		tipo = r.config.DefaultTipo
+	}
+	fee, exists := r.config.Providers[provider].Fees[tipo]
+	if !exists {
+		return 0
+	}
+
+	r.mu.Lock()
+	spend := r.tenantSpend(tenantID, r.now())
+	spend.spent += fee
+	spend.consultas++
+	spend.byProvider[provider] += fee
+	spent := spend.spent
+	r.mu.Unlock()
+
+	r.metrics.observeCharge(tenantID, tipo, provider, fee, spent, r.config.budgetFor(tenantID))
+	return fee
+}
+
+// Spend retorna o gasto do tenant no mês corrente
+func (r *Router) Spend(tenantID string) Spend {
+	r.mu.Lock()
+	spend := r.tenantSpend(tenantID, r.now())
+	result := Spend{
+		TenantID:   tenantID,
+		Period:     spend.period,
+		Currency:   r.config.Currency,
+		Limit:      r.config.budgetFor(tenantID),
+		Spent:      spend.spent,
+		Consultas:  spend.consultas,
+		ByProvider: make(map[string]float64, len(spend.byProvider)),
+	}
+	for provider, amount := range spend.byProvider {
+		result.ByProvider[provider] = amount
+	}
+	r.mu.Unlock()
+
+	if result.Limit > 0 {
+		remaining := result.Limit - result.Spent
+		if remaining < 0 {
+			remaining = 0
+		}
+		result.Remaining = &remaining
+	}
+	return result
+}
+
+// Decisions retorna as decisões mais recentes do tenant registadas na auditoria
+func (r *Router) Decisions(ctx context.Context, tenantID string, limit int) ([]Decision, error) {
+	if r.audit == nil {
+		return nil, nil
+	}
+	return r.audit.List(ctx, tenantID, limit)
+}
+
+// tenantSpend retorna o gasto do tenant no mês do instante, reiniciando-o quando o mês muda
+// Deve ser chamado com r.mu bloqueado
+func (r *Router) tenantSpend(tenantID string, now time.Time) *tenantSpend {
+	period := now.UTC().Format("2006-01")
+	spend, exists := r.spend[tenantID]
+	if !exists || spend.period != period {
+		spend = &tenantSpend{period: period, byProvider: make(map[string]float64)}
+		r.spend[tenantID] = spend
+	}
+	return spend
+}
+
+// missingFields retorna os campos exigidos que o provedor não devolve
+func missingFields(offered, required []string) []string {
+	available := make(map[string]bool, len(offered))
+	for _, field := range offered {
+		available[field] = true
+	}
+	var missing []string
+	for _, field := range required {
+		if !available[field] {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// hasReason indica se algum candidato foi excluído pelo motivo
+func hasReason(candidates []CandidateEvaluation, reason string) bool {
+	for _, candidate := range candidates {
+		if candidate.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Metrics contém as métricas Prometheus do roteamento por custo
+type Metrics struct {
+	decisionsCounter *prometheus.CounterVec
+	spendCounter     *prometheus.CounterVec
+	budgetGauge      *prometheus.GaugeVec
+}
+
+// NewMetrics cria e regista as métricas do roteamento por custo
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		decisionsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_routing_decisions_total",
+				Help: "Número total de decisões de roteamento por tipo, provedor selecionado e resultado",
+			},
+			[]string{"tipo", "provider", "outcome"},
+		),
+		spendCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_routing_spend_total",
+				Help: "Gasto acumulado em tarifas de consulta por provedor e tipo, na moeda configurada",
+			},
+			[]string{"provider", "tipo"},
+		),
+		budgetGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_routing_budget_used_ratio",
+				Help: "Fração do orçamento mensal de consultas consumida pelo tenant",
+			},
+			[]string{"tenant"},
+		),
+	}
+
+	registry.MustRegister(m.decisionsCounter, m.spendCounter, m.budgetGauge)
+	return m
+}
+
+// observeDecision regista o resultado de uma decisão de roteamento
+func (m *Metrics) observeDecision(decision *Decision) {
+	if m == nil {
+		return
+	}
+	m.decisionsCounter.WithLabelValues(decision.Tipo, decision.Selected, decision.Outcome).Inc()
+}
+
+// observeCharge regista a tarifa cobrada e a utilização do orçamento do tenant
+func (m *Metrics) observeCharge(tenantID, tipo, provider string, fee, spent, budget float64) {
+	if m == nil {
+		return
+	}
+	m.spendCounter.WithLabelValues(provider, tipo).Add(fee)
+	if budget > 0 {
+		m.budgetGauge.WithLabelValues(tenantID).Set(spent / budget)
+	}
+}
@@ -0,0 +1,227 @@
+/**
+ * @file router_test.go
+ * @description Testes do roteamento por custo das consultas entre provedores de crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/routing"
+)
+
+func newRouter(config routing.Config) (*routing.Router, *time.Time) {
+	now := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+	router := routing.NewRouter(config)
+	router.SetClock(func() time.Time { return now })
+	return router, &now
+}
+
+func routingConfig() routing.Config {
+	return routing.Config{
+		Currency: "AOA",
+		Providers: map[string]routing.ProviderOffer{
+			"serasa": {
+				Fees:   map[string]float64{"score": 120, "completa": 900},
+				Fields: []string{"score", "restricoes", "historico"},
+			},
+			"spc": {
+				Fees:   map[string]float64{"score": 80},
+				Fields: []string{"score"},
+			},
+			"boavista": {
+				Fees:   map[string]float64{"score": 100, "completa": 700},
+				Fields: []string{"score", "restricoes"},
+			},
+		},
+		DefaultTipo: "score",
+	}
+}
+
+func TestRouteSelectsCheapestProviderSatisfyingRequirements(t *testing.T) {
+	router, _ := newRouter(routingConfig())
+
+	decision, err := router.Route(context.Background(), routing.Requirement{
+		ConsultaID: "consulta-1",
+		TenantID:   "tenant-1",
+		Tipo:       "score",
+		Fields:     []string{"score", "restricoes"},
+		Candidates: []string{"serasa", "spc", "boavista"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, routing.OutcomeRouted, decision.Outcome)
+	assert.Equal(t, "boavista", decision.Selected)
+	assert.Equal(t, 100.0, decision.Fee)
+	assert.Equal(t, []string{"boavista", "serasa"}, decision.Providers)
+
+	reasons := map[string]string{}
+	for _, candidate := range decision.Candidates {
+		reasons[candidate.Provider] = candidate.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"serasa":   routing.ReasonFallback,
+		"spc":      routing.ReasonMissingFields,
+		"boavista": routing.ReasonSelected,
+	}, reasons)
+}
+
+func TestRouteDefaultTipoAndUnsupportedTipo(t *testing.T) {
+	router, _ := newRouter(routingConfig())
+
+	decision, err := router.Route(context.Background(), routing.Requirement{
+		TenantID:   "tenant-1",
+		Candidates: []string{"serasa", "spc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "score", decision.Tipo)
+	assert.Equal(t, "spc", decision.Selected)
+
+	decision, err = router.Route(context.Background(), routing.Requirement{
+		TenantID:   "tenant-1",
+		Tipo:       "relacionamento",
+		Candidates: []string{"serasa", "spc", "desconhecido"},
+	})
+	assert.ErrorIs(t, err, routing.ErrNoEligibleProvider)
+	require.NotNil(t, decision)
+	assert.Equal(t, routing.OutcomeNoProvider, decision.Outcome)
+	for _, candidate := range decision.Candidates {
+		assert.Equal(t, routing.ReasonNoRateCard, candidate.Reason)
+	}
+
+	config := routingConfig()
+	config.DefaultTipo = ""
+	router, _ = newRouter(config)
+	_, err = router.Route(context.Background(), routing.Requirement{TenantID: "tenant-1", Candidates: []string{"spc"}})
+	assert.ErrorIs(t, err, routing.ErrTipoRequired)
+}
+
+func TestRouteDegradedProvidersLast(t *testing.T) {
+	router, _ := newRouter(routingConfig())
+
+	decision, err := router.Route(context.Background(), routing.Requirement{
+		TenantID:   "tenant-1",
+		Tipo:       "score",
+		Candidates: []string{"serasa", "spc", "boavista"},
+		Degraded:   []string{"spc"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "boavista", decision.Selected)
+	assert.Equal(t, []string{"boavista", "serasa", "spc"}, decision.Providers)
+}
+
+func TestRouteTieBreaksByCandidateOrder(t *testing.T) {
+	config := routingConfig()
+	config.Providers["spc"] = routing.ProviderOffer{Fees: map[string]float64{"score": 100}}
+	router, _ := newRouter(config)
+
+	decision, err := router.Route(context.Background(), routing.Requirement{
+		TenantID:   "tenant-1",
+		Tipo:       "score",
+		Candidates: []string{"spc", "boavista"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spc", "boavista"}, decision.Providers)
+}
+
+func TestBudgetCapsAndMonthlyReset(t *testing.T) {
+	config := routingConfig()
+	config.DefaultMonthlyBudget = 1000
+	config.TenantBudgets = map[string]float64{"tenant-premium": 0}
+	router, now := newRouter(config)
+	ctx := context.Background()
+
+	requirement := routing.Requirement{
+		TenantID:   "tenant-1",
+		Tipo:       "completa",
+		Candidates: []string{"serasa", "boavista"},
+	}
+
+	decision, err := router.Route(ctx, requirement)
+	require.NoError(t, err)
+	assert.Equal(t, "boavista", decision.Selected)
+	assert.Equal(t, 700.0, router.Charge("tenant-1", "completa", decision.Selected))
+
+	// Restam 300: nenhuma consulta completa cabe no orçamento
+	decision, err = router.Route(ctx, requirement)
+	assert.ErrorIs(t, err, routing.ErrBudgetExceeded)
+	assert.Equal(t, routing.OutcomeBudgetExceeded, decision.Outcome)
+	assert.Equal(t, 700.0, decision.BudgetSpent)
+
+	// Uma consulta de score ainda cabe
+	decision, err = router.Route(ctx, routing.Requirement{TenantID: "tenant-1", Tipo: "score", Candidates: []string{"serasa"}})
+	require.NoError(t, err)
+	assert.Equal(t, "serasa", decision.Selected)
+
+	spend := router.Spend("tenant-1")
+	assert.Equal(t, "2025-03", spend.Period)
+	assert.Equal(t, 700.0, spend.Spent)
+	assert.Equal(t, 1, spend.Consultas)
+	require.NotNil(t, spend.Remaining)
+	assert.Equal(t, 300.0, *spend.Remaining)
+	assert.Equal(t, map[string]float64{"boavista": 700}, spend.ByProvider)
+
+	// Tenants com orçamento zero não são limitados
+	router.Charge("tenant-premium", "completa", "serasa")
+	router.Charge("tenant-premium", "completa", "serasa")
+	_, err = router.Route(ctx, routing.Requirement{TenantID: "tenant-premium", Tipo: "completa", Candidates: []string{"serasa"}})
+	require.NoError(t, err)
+	assert.Nil(t, router.Spend("tenant-premium").Remaining)
+
+	// O gasto é reiniciado no início do mês
+	*now = now.Add(2 * time.Hour)
+	_, err = router.Route(ctx, requirement)
+	require.NoError(t, err)
+	spend = router.Spend("tenant-1")
+	assert.Equal(t, "2025-04", spend.Period)
+	assert.Zero(t, spend.Spent)
+}
+
+func TestChargeIgnoresUnknownRates(t *testing.T) {
+	router, _ := newRouter(routingConfig())
+
+	assert.Zero(t, router.Charge("tenant-1", "completa", "spc"))
+	assert.Zero(t, router.Charge("tenant-1", "score", "desconhecido"))
+	assert.Equal(t, 80.0, router.Charge("tenant-1", "", "spc"), "sem tipo deve ser usado o tipo padrão")
+	assert.Equal(t, 80.0, router.Spend("tenant-1").Spent)
+}
+
+func TestDecisionsAuditTrail(t *testing.T) {
+	router, _ := newRouter(routingConfig())
+	router.SetAuditLog(routing.NewMemoryAuditLog(3))
+	ctx := context.Background()
+
+	for _, consultaID := range []string{"c1", "c2", "c3", "c4"} {
+		_, err := router.Route(ctx, routing.Requirement{
+			ConsultaID: consultaID,
+			TenantID:   "tenant-1",
+			Tipo:       "score",
+			Candidates: []string{"spc"},
+		})
+		require.NoError(t, err)
+	}
+	_, err := router.Route(ctx, routing.Requirement{ConsultaID: "outro", TenantID: "tenant-2", Tipo: "score", Candidates: []string{"spc"}})
+	require.NoError(t, err)
+
+	decisions, err := router.Decisions(ctx, "tenant-1", 0)
+	require.NoError(t, err)
+	require.Len(t, decisions, 2, "as decisões mais antigas devem ser descartadas")
+	assert.Equal(t, "c4", decisions[0].ConsultaID)
+	assert.Equal(t, "c3", decisions[1].ConsultaID)
+	assert.NotEmpty(t, decisions[0].ID)
+	assert.Equal(t, "AOA", decisions[0].Currency)
+
+	decisions, err = router.Decisions(ctx, "tenant-1", 1)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, "c4", decisions[0].ConsultaID)
+}
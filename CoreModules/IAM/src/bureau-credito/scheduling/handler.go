@@ -0,0 +1,207 @@
+/**
+ * @file handler.go
+ * @description API HTTP dos jobs agendados de consulta de carteiras do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scheduling
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TenantHeader identifica o credor autenticado pelo gateway
+const TenantHeader = "X-Tenant-ID"
+
+// jobRequest é o corpo de POST /scheduled-jobs
+type jobRequest struct {
+	Nome       string        `json:"nome"`
+	Market     string        `json:"market"`
+	Frequencia Frequencia    `json:"frequencia"`
+	Inicio     string        `json:"inicio,omitempty"` // RFC 3339
+	Itens      []ItemRequest `json:"itens"`
+}
+
+// Handler expõe a API dos jobs agendados:
+//
+//	POST   /scheduled-jobs                      cria um job de consulta recorrente da carteira
+//	GET    /scheduled-jobs                      lista os jobs do tenant
+//	GET    /scheduled-jobs/{id}                 obtém um job
+//	DELETE /scheduled-jobs/{id}                 remove um job e o seu histórico
+//	POST   /scheduled-jobs/{id}/pause           pausa um job
+//	POST   /scheduled-jobs/{id}/resume          retoma um job, opcionalmente com consentimentos renovados
+//	GET    /scheduled-jobs/{id}/runs?limit=N    lista as execuções, mais recentes primeiro
+//	GET    /scheduled-jobs/{id}/runs/{runId}    obtém uma execução com os deltas
+type Handler struct {
+	scheduler *Scheduler
+}
+
+// NewHandler cria o handler da API dos jobs agendados
+func NewHandler(scheduler *Scheduler) *Handler {
+	return &Handler{scheduler: scheduler}
+}
+
+// Register regista as rotas no multiplexador
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/scheduled-jobs", h)
+	mux.Handle("/scheduled-jobs/", h)
+}
+
+// ServeHTTP encaminha o pedido pelo caminho e pelo método
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.Header.Get(TenantHeader)
+	if tenantID == "" {
+		http.Error(w, "cabeçalho "+TenantHeader+" obrigatório", http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/scheduled-jobs"), "/")
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, "/")
+	}
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		h.create(w, r, tenantID)
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		jobs, err := h.scheduler.ListJobs(r.Context(), tenantID)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, jobs)
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		job, err := h.scheduler.GetJob(r.Context(), tenantID, segments[0])
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		if err := h.scheduler.DeleteJob(r.Context(), tenantID, segments[0]); err != nil {
+			h.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(segments) == 2 && segments[1] == "pause" && r.Method == http.MethodPost:
+		job, err := h.scheduler.PauseJob(r.Context(), tenantID, segments[0])
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	case len(segments) == 2 && segments[1] == "resume" && r.Method == http.MethodPost:
+		h.resume(w, r, tenantID, segments[0])
+	case len(segments) == 2 && segments[1] == "runs" && r.Method == http.MethodGet:
+		limit := 0
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "limit inválido", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		runs, err := h.scheduler.ListRuns(r.Context(), tenantID, segments[0], limit)
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, runs)
+	case len(segments) == 3 && segments[1] == "runs" && r.Method == http.MethodGet:
+		run, err := h.scheduler.GetRun(r.Context(), tenantID, segments[0], segments[2])
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, run)
+	case len(segments) > 3 || (len(segments) >= 2 && segments[1] != "pause" && segments[1] != "resume" && segments[1] != "runs"):
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// create regista um job do tenant
+func (h *Handler) create(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var req jobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<20)).Decode(&req); err != nil {
+		http.Error(w, "corpo inválido", http.StatusBadRequest)
+		return
+	}
+
+	var inicio *time.Time
+	if req.Inicio != "" {
+		parsed, err := time.Parse(time.RFC3339, req.Inicio)
+		if err != nil {
+			http.Error(w, "inicio inválido", http.StatusBadRequest)
+			return
+		}
+		inicio = &parsed
+	}
+
+	job, err := h.scheduler.CreateJob(r.Context(), CreateJobRequest{
+		TenantID:   tenantID,
+		Nome:       req.Nome,
+		Market:     req.Market,
+		Frequencia: req.Frequencia,
+		Inicio:     inicio,
+		Itens:      req.Itens,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, job)
+}
+
+// resume retoma um job do tenant; o corpo é opcional
+func (h *Handler) resume(w http.ResponseWriter, r *http.Request, tenantID, jobID string) {
+	var req ResumeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, "corpo inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	job, err := h.scheduler.ResumeJob(r.Context(), tenantID, jobID, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// writeError converte os erros do agendamento em respostas HTTP
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidJob):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrJobNotFound), errors.Is(err, ErrRunNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrConsentRequired), errors.Is(err, ErrConsentInvalid):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Error().Err(err).Msg("Erro na API de consultas agendadas")
+		http.Error(w, "erro interno", http.StatusInternalServerError)
+	}
+}
+
+// writeJSON serializa a resposta JSON
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error().Err(err).Msg("Erro ao serializar resposta da API de consultas agendadas")
+	}
+}
@@ -0,0 +1,381 @@
+/**
+ * @file models.go
+ * @description Modelos dos jobs agendados de consulta de carteiras do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frequencia define a recorrência das execuções de um job
+type Frequencia string
+
+const (
+	FrequenciaDiaria  Frequencia = "DIARIA"
+	FrequenciaSemanal Frequencia = "SEMANAL"
+	FrequenciaMensal  Frequencia = "MENSAL"
+)
+
+// IsValid indica se a frequência é suportada
+func (f Frequencia) IsValid() bool {
+	return f == FrequenciaDiaria || f == FrequenciaSemanal || f == FrequenciaMensal
+}
+
+// next retorna a execução seguinte à indicada
+func (f Frequencia) next(t time.Time) time.Time {
+	switch f {
+	case FrequenciaSemanal:
+		return t.AddDate(0, 0, 7)
+	case FrequenciaMensal:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// JobStatus define o estado de um job ou de um documento da carteira
+type JobStatus string
+
+const (
+	StatusActive JobStatus = "ACTIVE"
+	StatusPaused JobStatus = "PAUSED" // Pausado pelo credor ou por falta de consentimento
+)
+
+// RunStatus define o resultado de uma execução
+type RunStatus string
+
+const (
+	RunCompleted RunStatus = "COMPLETED" // Todos os documentos ativos consultados
+	RunPartial   RunStatus = "PARTIAL"   // Alguns documentos com erro ou sem consentimento
+	RunFailed    RunStatus = "FAILED"    // Nenhum documento consultado com sucesso
+	RunPaused    RunStatus = "PAUSED"    // Job pausado antes das consultas: nenhum consentimento válido
+)
+
+// Erros dos jobs agendados
+var (
+	ErrJobNotFound     = errors.New("job de consulta não encontrado")
+	ErrRunNotFound     = errors.New("execução do job não encontrada")
+	ErrInvalidJob      = errors.New("job de consulta inválido")
+	ErrConsentRequired = errors.New("consentimento obrigatório para consultas agendadas no mercado")
+	ErrConsentInvalid  = errors.New("consentimento inválido ou expirado")
+)
+
+// Restricao é uma restrição de crédito registada para o documento
+type Restricao struct {
+	ID             string    `json:"id"`
+	Tipo           string    `json:"tipo"`
+	Valor          float64   `json:"valor"`
+	FonteNome      string    `json:"fonteNome,omitempty"`
+	DataOcorrencia time.Time `json:"dataOcorrencia"`
+}
+
+// Snapshot é o estado do documento obtido numa execução
+type Snapshot struct {
+	Score        *int        `json:"score,omitempty"`
+	Restricoes   []Restricao `json:"restricoes,omitempty"`
+	ConsultadoEm time.Time   `json:"consultadoEm"`
+}
+
+// JobItem é um documento da carteira com o seu consentimento e o estado da última consulta
+type JobItem struct {
+	ID string `json:"id"`
+
+	// Documento só é usado nas consultas; as respostas e os deltas levam a versão mascarada
+	Documento          string `json:"-"`
+	DocumentoMascarado string `json:"documento"`
+	TipoEntidade       string `json:"tipoEntidade,omitempty"` // PF ou PJ
+	Referencia         string `json:"referencia,omitempty"`   // Identificador do contrato no credor
+
+	ConsentimentoID string    `json:"consentimentoId,omitempty"`
+	Status          JobStatus `json:"status"`
+	MotivoPausa     string    `json:"motivoPausa,omitempty"`
+	UltimoErro      string    `json:"ultimoErro,omitempty"`
+
+	// Estado da última consulta bem-sucedida, base do delta da execução seguinte
+	UltimoScore          *int       `json:"ultimoScore,omitempty"`
+	RestricoesConhecidas []string   `json:"-"`
+	ConsultadoEm         *time.Time `json:"consultadoEm,omitempty"`
+}
+
+// Job é uma consulta recorrente de uma carteira de documentos do credor
+type Job struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenantId"`
+	Nome       string     `json:"nome"`
+	Market     string     `json:"market"`
+	Frequencia Frequencia `json:"frequencia"`
+	Itens      []*JobItem `json:"itens"`
+
+	Status      JobStatus  `json:"status"`
+	MotivoPausa string     `json:"motivoPausa,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastRunAt   *time.Time `json:"lastRunAt,omitempty"`
+	NextRunAt   time.Time  `json:"nextRunAt"`
+}
+
+// item retorna o documento da carteira com o identificador
+func (j *Job) item(itemID string) *JobItem {
+	for _, item := range j.Itens {
+		if item.ID == itemID {
+			return item
+		}
+	}
+	return nil
+}
+
+// activeItems conta os documentos ativos da carteira
+func (j *Job) activeItems() int {
+	count := 0
+	for _, item := range j.Itens {
+		if item.Status == StatusActive {
+			count++
+		}
+	}
+	return count
+}
+
+// clone copia o job e os documentos da carteira
+func (j *Job) clone() *Job {
+	copied := *j
+	copied.Itens = make([]*JobItem, len(j.Itens))
+	for i, item := range j.Itens {
+		copied.Itens[i] = item.clone()
+	}
+	if j.LastRunAt != nil {
+		lastRun := *j.LastRunAt
+		copied.LastRunAt = &lastRun
+	}
+	return &copied
+}
+
+// clone copia o documento, incluindo o estado da última consulta
+func (i *JobItem) clone() *JobItem {
+	copied := *i
+	copied.RestricoesConhecidas = append([]string(nil), i.RestricoesConhecidas...)
+	if i.UltimoScore != nil {
+		score := *i.UltimoScore
+		copied.UltimoScore = &score
+	}
+	if i.ConsultadoEm != nil {
+		consultado := *i.ConsultadoEm
+		copied.ConsultadoEm = &consultado
+	}
+	return &copied
+}
+
+// Delta é a alteração de um documento entre duas execuções
+type Delta struct {
+	ItemID                  string      `json:"itemId"`
+	Documento               string      `json:"documento"` // Mascarado
+	Referencia              string      `json:"referencia,omitempty"`
+	ScoreAnterior           *int        `json:"scoreAnterior,omitempty"`
+	ScoreAtual              *int        `json:"scoreAtual,omitempty"`
+	Variacao                int         `json:"variacao,omitempty"`
+	NovasRestricoes         []Restricao `json:"novasRestricoes,omitempty"`
+	RestricoesRegularizadas []string    `json:"restricoesRegularizadas,omitempty"`
+}
+
+// Run é o registo de uma execução do job
+// A primeira consulta de cada documento serve de referência e não gera delta
+type Run struct {
+	ID               string    `json:"id"`
+	JobID            string    `json:"jobId"`
+	TenantID         string    `json:"tenantId"`
+	Status           RunStatus `json:"status"`
+	IniciadaEm       time.Time `json:"iniciadaEm"`
+	ConcluidaEm      time.Time `json:"concluidaEm"`
+	Consultados      int       `json:"consultados"`
+	Falhas           int       `json:"falhas"`
+	SemConsentimento int       `json:"semConsentimento"`
+	Deltas           []Delta   `json:"deltas"`
+	Erro             string    `json:"erro,omitempty"`
+}
+
+// Store define a persistência dos jobs e do histórico das execuções
+type Store interface {
+	// SaveJob cria ou atualiza um job
+	SaveJob(ctx context.Context, job *Job) error
+
+	// GetJob recupera um job do tenant
+	GetJob(ctx context.Context, tenantID, jobID string) (*Job, error)
+
+	// ListJobs lista os jobs do tenant, por ordem de criação
+	ListJobs(ctx context.Context, tenantID string) ([]*Job, error)
+
+	// DueJobs lista os jobs ativos cuja próxima execução já passou
+	DueJobs(ctx context.Context, now time.Time, limit int) ([]*Job, error)
+
+	// DeleteJob remove um job do tenant e o seu histórico
+	DeleteJob(ctx context.Context, tenantID, jobID string) error
+
+	// SaveRun acrescenta uma execução ao histórico do job
+	SaveRun(ctx context.Context, run *Run) error
+
+	// GetRun recupera uma execução de um job do tenant
+	GetRun(ctx context.Context, tenantID, jobID, runID string) (*Run, error)
+
+	// ListRuns lista as execuções de um job do tenant, mais recentes primeiro
+	ListRuns(ctx context.Context, tenantID, jobID string, limit int) ([]*Run, error)
+}
+
+// InMemoryStore mantém os jobs e as últimas execuções de cada job em memória
+type InMemoryStore struct {
+	jobs       map[string]*Job
+	runs       map[string][]*Run
+	maxHistory int
+	mutex      sync.RWMutex
+}
+
+// NewInMemoryStore cria um armazenamento em memória que guarda até maxHistory execuções por
+// job; um valor não positivo usa DefaultMaxHistory
+func NewInMemoryStore(maxHistory int) *InMemoryStore {
+	if maxHistory <= 0 {
+		maxHistory = DefaultMaxHistory
+	}
+	return &InMemoryStore{
+		jobs:       make(map[string]*Job),
+		runs:       make(map[string][]*Run),
+		maxHistory: maxHistory,
+	}
+}
+
+// SaveJob grava uma cópia do job
+func (s *InMemoryStore) SaveJob(ctx context.Context, job *Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// GetJob retorna uma cópia do job
+func (s *InMemoryStore) GetJob(ctx context.Context, tenantID, jobID string) (*Job, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.TenantID != tenantID {
+		return nil, ErrJobNotFound
+	}
+	return job.clone(), nil
+}
+
+// ListJobs retorna cópias dos jobs do tenant
+func (s *InMemoryStore) ListJobs(ctx context.Context, tenantID string) ([]*Job, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var jobs []*Job
+	for _, job := range s.jobs {
+		if job.TenantID == tenantID {
+			jobs = append(jobs, job.clone())
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// DueJobs retorna cópias dos jobs ativos a executar, os mais atrasados primeiro
+func (s *InMemoryStore) DueJobs(ctx context.Context, now time.Time, limit int) ([]*Job, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var jobs []*Job
+	for _, job := range s.jobs {
+		if job.Status == StatusActive && !job.NextRunAt.After(now) {
+			jobs = append(jobs, job.clone())
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].NextRunAt.Before(jobs[j].NextRunAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// DeleteJob remove o job e o seu histórico
+func (s *InMemoryStore) DeleteJob(ctx context.Context, tenantID, jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.TenantID != tenantID {
+		return ErrJobNotFound
+	}
+	delete(s.jobs, jobID)
+	delete(s.runs, jobID)
+	return nil
+}
+
+// SaveRun acrescenta a execução, descartando as mais antigas além do limite do histórico
+func (s *InMemoryStore) SaveRun(ctx context.Context, run *Run) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := *run
+	copied.Deltas = append([]Delta(nil), run.Deltas...)
+	runs := append(s.runs[run.JobID], &copied)
+	if len(runs) > s.maxHistory {
+		runs = runs[len(runs)-s.maxHistory:]
+	}
+	s.runs[run.JobID] = runs
+	return nil
+}
+
+// GetRun retorna uma cópia da execução
+func (s *InMemoryStore) GetRun(ctx context.Context, tenantID, jobID, runID string) (*Run, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, run := range s.runs[jobID] {
+		if run.ID == runID && run.TenantID == tenantID {
+			copied := *run
+			return &copied, nil
+		}
+	}
+	return nil, ErrRunNotFound
+}
+
+// ListRuns retorna cópias das execuções do job, mais recentes primeiro
+func (s *InMemoryStore) ListRuns(ctx context.Context, tenantID, jobID string, limit int) ([]*Run, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || job.TenantID != tenantID {
+		return nil, ErrJobNotFound
+	}
+
+	runs := s.runs[jobID]
+	result := make([]*Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		copied := *runs[i]
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+// maskDocumento mantém apenas os dois últimos dígitos do documento
+func maskDocumento(documento string) string {
+	documento = strings.TrimSpace(documento)
+	if len(documento) <= 2 {
+		return strings.Repeat("*", len(documento))
+	}
+	return strings.Repeat("*", len(documento)-2) + documento[len(documento)-2:]
+}
@@ -0,0 +1,628 @@
+/**
+ * @file scheduler.go
+ * @description Agendamento de consultas recorrentes de carteiras com registo de deltas e pausa por consentimento
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Valores padrão do agendamento
+const (
+	DefaultPollInterval = time.Minute
+	DefaultBatchSize    = 10
+	DefaultMaxItens     = 10000
+	DefaultMaxHistory   = 100
+	DefaultRunsLimit    = 20
+)
+
+// Consultor executa a consulta de um documento da carteira
+type Consultor interface {
+	ConsultarCarteira(ctx context.Context, job *Job, item *JobItem) (*Snapshot, error)
+}
+
+// ConsentVerifier valida o consentimento do titular do documento no mercado
+type ConsentVerifier interface {
+	VerificarConsentimento(ctx context.Context, market, documento, consentimentoID string) (bool, error)
+}
+
+// Config define o agendamento dos jobs
+type Config struct {
+	PollInterval time.Duration `json:"pollInterval"` // Frequência com que os jobs vencidos são procurados
+	BatchSize    int           `json:"batchSize"`    // Jobs executados por ciclo
+	MaxItens     int           `json:"maxItens"`     // Documentos por carteira
+
+	// ConsentimentoObrigatorio indica, por mercado em minúsculas, se as consultas agendadas
+	// exigem consentimento; os mercados sem entrada usam ConsentimentoPadrao
+	ConsentimentoObrigatorio map[string]bool `json:"consentimentoObrigatorio"`
+	ConsentimentoPadrao      bool            `json:"consentimentoPadrao"`
+}
+
+// DefaultConfig retorna a configuração padrão do agendamento
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:        DefaultPollInterval,
+		BatchSize:           DefaultBatchSize,
+		MaxItens:            DefaultMaxItens,
+		ConsentimentoPadrao: true,
+	}
+}
+
+// consentRequired indica se o mercado exige consentimento para as consultas agendadas
+func (c Config) consentRequired(market string) bool {
+	if required, ok := c.ConsentimentoObrigatorio[strings.ToLower(market)]; ok {
+		return required
+	}
+	return c.ConsentimentoPadrao
+}
+
+// ItemRequest representa um documento da carteira a consultar
+type ItemRequest struct {
+	Documento       string `json:"documento"`
+	TipoEntidade    string `json:"tipoEntidade,omitempty"`
+	Referencia      string `json:"referencia,omitempty"`
+	ConsentimentoID string `json:"consentimentoId,omitempty"`
+}
+
+// CreateJobRequest representa o pedido de um job de consulta recorrente
+type CreateJobRequest struct {
+	TenantID   string        `json:"tenantId"`
+	Nome       string        `json:"nome"`
+	Market     string        `json:"market"`
+	Frequencia Frequencia    `json:"frequencia"`
+	Inicio     *time.Time    `json:"inicio,omitempty"` // Primeira execução; vazio executa no ciclo seguinte
+	Itens      []ItemRequest `json:"itens"`
+}
+
+// ResumeRequest representa a retoma de um job, opcionalmente com consentimentos renovados
+type ResumeRequest struct {
+	// Consentimentos associa o identificador do documento da carteira ao novo consentimento
+	Consentimentos map[string]string `json:"consentimentos,omitempty"`
+}
+
+// Scheduler executa os jobs de consulta das carteiras na sua frequência, verifica o
+// consentimento de cada documento antes de cada execução e regista os deltas
+type Scheduler struct {
+	store     Store
+	consultor Consultor
+	consent   ConsentVerifier
+	config    Config
+	metrics   *Metrics
+	now       func() time.Time
+
+	// mutex serializa as alterações dos jobs entre a API e o worker
+	mutex sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler cria o agendador; consent pode ser nil quando nenhum mercado exige consentimento
+func NewScheduler(store Store, consultor Consultor, consent ConsentVerifier, config Config) *Scheduler {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MaxItens <= 0 {
+		config.MaxItens = defaults.MaxItens
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:     store,
+		consultor: consultor,
+		consent:   consent,
+		config:    config,
+		now:       time.Now,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetClock substitui o relógio usado no agendamento das execuções
+func (s *Scheduler) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetMetrics define as métricas Prometheus do agendamento
+func (s *Scheduler) SetMetrics(metrics *Metrics) {
+	s.metrics = metrics
+}
+
+// Start inicia o worker que executa os jobs vencidos
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RunOnce(s.ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Error().Err(err).Msg("Erro no ciclo de consultas agendadas do Bureau de Crédito")
+				}
+			}
+		}
+	}()
+}
+
+// Stop interrompe o worker; uma execução interrompida é repetida no arranque seguinte
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// CreateJob valida o pedido e o consentimento de cada documento e regista o job
+func (s *Scheduler) CreateJob(ctx context.Context, req CreateJobRequest) (*Job, error) {
+	req.Nome = strings.TrimSpace(req.Nome)
+	req.Market = strings.TrimSpace(req.Market)
+	if req.TenantID == "" || req.Nome == "" || req.Market == "" {
+		return nil, fmt.Errorf("%w: tenant, nome e mercado são obrigatórios", ErrInvalidJob)
+	}
+	if !req.Frequencia.IsValid() {
+		return nil, fmt.Errorf("%w: frequência desconhecida %q", ErrInvalidJob, req.Frequencia)
+	}
+	if len(req.Itens) == 0 {
+		return nil, fmt.Errorf("%w: a carteira tem de ter pelo menos um documento", ErrInvalidJob)
+	}
+	if len(req.Itens) > s.config.MaxItens {
+		return nil, fmt.Errorf("%w: no máximo %d documentos por carteira", ErrInvalidJob, s.config.MaxItens)
+	}
+
+	now := s.now()
+	job := &Job{
+		ID:         uuid.New().String(),
+		TenantID:   req.TenantID,
+		Nome:       req.Nome,
+		Market:     req.Market,
+		Frequencia: req.Frequencia,
+		Status:     StatusActive,
+		CreatedAt:  now,
+		NextRunAt:  now,
+	}
+	if req.Inicio != nil && req.Inicio.After(now) {
+		job.NextRunAt = *req.Inicio
+	}
+
+	seen := make(map[string]bool, len(req.Itens))
+	for _, itemReq := range req.Itens {
+		documento := strings.TrimSpace(itemReq.Documento)
+		if documento == "" {
+			return nil, fmt.Errorf("%w: documento vazio na carteira", ErrInvalidJob)
+		}
+		if seen[documento] {
+			return nil, fmt.Errorf("%w: documento %s repetido na carteira", ErrInvalidJob, maskDocumento(documento))
+		}
+		seen[documento] = true
+
+		if err := s.verifyConsent(ctx, req.Market, documento, itemReq.ConsentimentoID); err != nil {
+			return nil, fmt.Errorf("documento %s: %w", maskDocumento(documento), err)
+		}
+		job.Itens = append(job.Itens, &JobItem{
+			ID:                 uuid.New().String(),
+			Documento:          documento,
+			DocumentoMascarado: maskDocumento(documento),
+			TipoEntidade:       itemReq.TipoEntidade,
+			Referencia:         itemReq.Referencia,
+			ConsentimentoID:    itemReq.ConsentimentoID,
+			Status:             StatusActive,
+		})
+	}
+
+	if err := s.store.SaveJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("erro ao gravar job de consulta: %w", err)
+	}
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("tenant_id", job.TenantID).
+		Str("market", job.Market).
+		Str("frequencia", string(job.Frequencia)).
+		Int("itens", len(job.Itens)).
+		Time("next_run_at", job.NextRunAt).
+		Msg("Job de consulta agendada criado")
+	return job, nil
+}
+
+// GetJob retorna um job do tenant
+func (s *Scheduler) GetJob(ctx context.Context, tenantID, jobID string) (*Job, error) {
+	return s.store.GetJob(ctx, tenantID, jobID)
+}
+
+// ListJobs retorna os jobs do tenant
+func (s *Scheduler) ListJobs(ctx context.Context, tenantID string) ([]*Job, error) {
+	return s.store.ListJobs(ctx, tenantID)
+}
+
+// PauseJob suspende as execuções do job até à sua retoma
+func (s *Scheduler) PauseJob(ctx context.Context, tenantID, jobID string) (*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, err := s.store.GetJob(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = StatusPaused
+	job.MotivoPausa = "pausado pelo credor"
+	if err := s.store.SaveJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("erro ao gravar job de consulta: %w", err)
+	}
+
+	log.Info().Str("job_id", jobID).Str("tenant_id", tenantID).Msg("Job de consulta agendada pausado")
+	return job, nil
+}
+
+// ResumeJob retoma o job: aplica os consentimentos renovados, volta a verificar o consentimento
+// dos documentos pausados e reativa os que o tenham válido. A retoma é recusada quando nenhum
+// documento fica ativo. Uma execução em atraso é feita no ciclo seguinte
+func (s *Scheduler) ResumeJob(ctx context.Context, tenantID, jobID string, req ResumeRequest) (*Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, err := s.store.GetJob(ctx, tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	for itemID, consentimentoID := range req.Consentimentos {
+		item := job.item(itemID)
+		if item == nil {
+			return nil, fmt.Errorf("%w: documento %s não pertence à carteira", ErrInvalidJob, itemID)
+		}
+		item.ConsentimentoID = strings.TrimSpace(consentimentoID)
+	}
+
+	for _, item := range job.Itens {
+		if item.Status == StatusActive {
+			continue
+		}
+		if err := s.verifyConsent(ctx, job.Market, item.Documento, item.ConsentimentoID); err != nil {
+			if errors.Is(err, ErrConsentRequired) || errors.Is(err, ErrConsentInvalid) {
+				item.MotivoPausa = err.Error()
+				continue
+			}
+			return nil, err
+		}
+		item.Status = StatusActive
+		item.MotivoPausa = ""
+	}
+	if job.activeItems() == 0 {
+		return nil, fmt.Errorf("%w: nenhum documento da carteira tem consentimento válido", ErrConsentInvalid)
+	}
+
+	job.Status = StatusActive
+	job.MotivoPausa = ""
+	if err := s.store.SaveJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("erro ao gravar job de consulta: %w", err)
+	}
+
+	log.Info().
+		Str("job_id", jobID).
+		Str("tenant_id", tenantID).
+		Int("itens_ativos", job.activeItems()).
+		Msg("Job de consulta agendada retomado")
+	return job, nil
+}
+
+// DeleteJob remove o job e o seu histórico; nenhuma consulta é feita depois
+func (s *Scheduler) DeleteJob(ctx context.Context, tenantID, jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.store.DeleteJob(ctx, tenantID, jobID); err != nil {
+		return err
+	}
+	log.Info().Str("job_id", jobID).Str("tenant_id", tenantID).Msg("Job de consulta agendada removido")
+	return nil
+}
+
+// ListRuns retorna o histórico de execuções do job, mais recentes primeiro
+func (s *Scheduler) ListRuns(ctx context.Context, tenantID, jobID string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = DefaultRunsLimit
+	}
+	return s.store.ListRuns(ctx, tenantID, jobID, limit)
+}
+
+// GetRun retorna uma execução do job, com os deltas
+func (s *Scheduler) GetRun(ctx context.Context, tenantID, jobID, runID string) (*Run, error) {
+	return s.store.GetRun(ctx, tenantID, jobID, runID)
+}
+
+// RunOnce executa os jobs vencidos e retorna quantos foram executados
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	due, err := s.store.DueJobs(ctx, s.now(), s.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao listar jobs vencidos: %w", err)
+	}
+	for _, job := range due {
+		if err := s.execute(ctx, job); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), nil
+}
+
+// execute verifica o consentimento de cada documento ativo, consulta os que o têm válido e
+// regista a execução com os deltas em relação à execução anterior. Os documentos sem
+// consentimento válido ficam pausados; sem nenhum documento ativo, o job é pausado
+func (s *Scheduler) execute(ctx context.Context, job *Job) error {
+	now := s.now()
+	run := &Run{
+		ID:         uuid.New().String(),
+		JobID:      job.ID,
+		TenantID:   job.TenantID,
+		IniciadaEm: now,
+		Deltas:     []Delta{},
+	}
+
+	var eligible []*JobItem
+	for _, item := range job.Itens {
+		if item.Status != StatusActive {
+			continue
+		}
+		if err := s.verifyConsent(ctx, job.Market, item.Documento, item.ConsentimentoID); err != nil {
+			if errors.Is(err, ErrConsentRequired) || errors.Is(err, ErrConsentInvalid) {
+				item.Status = StatusPaused
+				item.MotivoPausa = err.Error()
+				run.SemConsentimento++
+				s.metrics.observeConsulta(job.Market, "no_consent")
+				continue
+			}
+			item.UltimoErro = err.Error()
+			run.Falhas++
+			s.metrics.observeConsulta(job.Market, "error")
+			continue
+		}
+		eligible = append(eligible, item)
+	}
+
+	if job.activeItems() == 0 {
+		job.Status = StatusPaused
+		job.MotivoPausa = "nenhum documento da carteira tem consentimento válido"
+		run.Status = RunPaused
+		run.Erro = job.MotivoPausa
+		log.Warn().
+			Str("job_id", job.ID).
+			Str("tenant_id", job.TenantID).
+			Str("market", job.Market).
+			Msg("Job de consulta agendada pausado por falta de consentimento")
+	}
+
+	for _, item := range eligible {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		snapshot, err := s.consultor.ConsultarCarteira(ctx, job, item)
+		if err != nil {
+			item.UltimoErro = err.Error()
+			run.Falhas++
+			s.metrics.observeConsulta(job.Market, "error")
+			continue
+		}
+		if delta := diff(item, snapshot); delta != nil {
+			run.Deltas = append(run.Deltas, *delta)
+		}
+		advance(item, snapshot, now)
+		run.Consultados++
+		s.metrics.observeConsulta(job.Market, "ok")
+	}
+
+	if run.Status == "" {
+		switch {
+		case run.Consultados == 0:
+			run.Status = RunFailed
+		case run.Falhas > 0 || run.SemConsentimento > 0:
+			run.Status = RunPartial
+		default:
+			run.Status = RunCompleted
+		}
+	}
+	run.ConcluidaEm = s.now()
+	job.LastRunAt = &now
+	job.NextRunAt = nextRun(job.Frequencia, job.NextRunAt, now)
+
+	s.finish(ctx, job, run)
+	return nil
+}
+
+// finish grava o resultado da execução sobre o estado atual do job: um job removido durante a
+// execução é descartado e uma pausa pedida pelo credor entretanto é mantida
+func (s *Scheduler) finish(ctx context.Context, job *Job, run *Run) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, err := s.store.GetJob(ctx, job.TenantID, job.ID)
+	if err != nil {
+		return
+	}
+	for i, item := range current.Itens {
+		if updated := job.item(item.ID); updated != nil {
+			// Um consentimento renovado durante a execução prevalece
+			updated.ConsentimentoID = item.ConsentimentoID
+			current.Itens[i] = updated
+		}
+	}
+	if current.Status == StatusActive {
+		current.Status = job.Status
+		current.MotivoPausa = job.MotivoPausa
+	}
+	current.LastRunAt = job.LastRunAt
+	current.NextRunAt = job.NextRunAt
+
+	if err := s.store.SaveJob(ctx, current); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Erro ao gravar job de consulta agendada")
+		return
+	}
+	if err := s.store.SaveRun(ctx, run); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Str("run_id", run.ID).Msg("Erro ao gravar execução do job de consulta")
+	}
+	s.metrics.observeRun(job.Market, run.Status)
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("run_id", run.ID).
+		Str("tenant_id", job.TenantID).
+		Str("status", string(run.Status)).
+		Int("consultados", run.Consultados).
+		Int("falhas", run.Falhas).
+		Int("sem_consentimento", run.SemConsentimento).
+		Int("deltas", len(run.Deltas)).
+		Msg("Execução de job de consulta agendada concluída")
+}
+
+// verifyConsent exige um consentimento válido quando o mercado o torna obrigatório
+func (s *Scheduler) verifyConsent(ctx context.Context, market, documento, consentimentoID string) error {
+	if !s.config.consentRequired(market) {
+		return nil
+	}
+	if consentimentoID == "" {
+		return fmt.Errorf("%w %s", ErrConsentRequired, market)
+	}
+	if s.consent == nil {
+		return fmt.Errorf("%w: verificação de consentimento não configurada", ErrConsentInvalid)
+	}
+	valid, err := s.consent.VerificarConsentimento(ctx, market, documento, consentimentoID)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar consentimento: %w", err)
+	}
+	if !valid {
+		return ErrConsentInvalid
+	}
+	return nil
+}
+
+// diff compara a consulta com a anterior do documento; a primeira consulta não gera delta
+func diff(item *JobItem, snapshot *Snapshot) *Delta {
+	if item.ConsultadoEm == nil {
+		return nil
+	}
+
+	delta := &Delta{
+		ItemID:     item.ID,
+		Documento:  item.DocumentoMascarado,
+		Referencia: item.Referencia,
+	}
+	changed := false
+	if item.UltimoScore != nil && snapshot.Score != nil && *item.UltimoScore != *snapshot.Score {
+		anterior, atual := *item.UltimoScore, *snapshot.Score
+		delta.ScoreAnterior = &anterior
+		delta.ScoreAtual = &atual
+		delta.Variacao = atual - anterior
+		changed = true
+	}
+
+	known := make(map[string]bool, len(item.RestricoesConhecidas))
+	for _, id := range item.RestricoesConhecidas {
+		known[id] = true
+	}
+	current := make(map[string]bool, len(snapshot.Restricoes))
+	for _, restricao := range snapshot.Restricoes {
+		current[restricao.ID] = true
+		if !known[restricao.ID] {
+			delta.NovasRestricoes = append(delta.NovasRestricoes, restricao)
+			changed = true
+		}
+	}
+	for _, id := range item.RestricoesConhecidas {
+		if !current[id] {
+			delta.RestricoesRegularizadas = append(delta.RestricoesRegularizadas, id)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return delta
+}
+
+// advance guarda a consulta como base do delta da execução seguinte
+func advance(item *JobItem, snapshot *Snapshot, now time.Time) {
+	ids := make([]string, 0, len(snapshot.Restricoes))
+	for _, restricao := range snapshot.Restricoes {
+		ids = append(ids, restricao.ID)
+	}
+	item.RestricoesConhecidas = ids
+	if snapshot.Score != nil {
+		score := *snapshot.Score
+		item.UltimoScore = &score
+	}
+	item.ConsultadoEm = &now
+	item.UltimoErro = ""
+}
+
+// nextRun avança o agendamento na frequência do job; as execuções perdidas (por exemplo, com
+// o serviço parado) não são repetidas
+func nextRun(frequencia Frequencia, scheduled, now time.Time) time.Time {
+	next := frequencia.next(scheduled)
+	for !next.After(now) {
+		next = frequencia.next(next)
+	}
+	return next
+}
+
+// Metrics contém as métricas Prometheus do agendamento
+type Metrics struct {
+	runsCounter      *prometheus.CounterVec
+	consultasCounter *prometheus.CounterVec
+}
+
+// NewMetrics cria e regista as métricas do agendamento
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		runsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_scheduled_runs_total",
+				Help: "Número total de execuções de jobs de consulta agendada pelo resultado",
+			},
+			[]string{"market", "status"},
+		),
+		consultasCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_scheduled_consultas_total",
+				Help: "Número total de consultas agendadas de documentos pelo resultado (ok, error, no_consent)",
+			},
+			[]string{"market", "result"},
+		),
+	}
+
+	registry.MustRegister(m.runsCounter, m.consultasCounter)
+	return m
+}
+
+// observeRun regista o resultado de uma execução
+func (m *Metrics) observeRun(market string, status RunStatus) {
+	if m == nil {
+		return
+	}
+	m.runsCounter.WithLabelValues(market, string(status)).Inc()
+}
+
+// observeConsulta regista o resultado da consulta de um documento
+func (m *Metrics) observeConsulta(market, result string) {
+	if m == nil {
+		return
+	}
+	m.consultasCounter.WithLabelValues(market, result).Inc()
+}
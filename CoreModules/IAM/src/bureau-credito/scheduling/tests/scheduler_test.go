@@ -0,0 +1,466 @@
+/**
+ * @file scheduler_test.go
+ * @description Testes dos jobs agendados de consulta de carteiras do Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/scheduling"
+)
+
+// testClock é um relógio ajustável para o agendamento das execuções
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = now
+}
+
+// fakeConsultor devolve o estado configurado de cada documento
+type fakeConsultor struct {
+	mutex     sync.Mutex
+	snapshots map[string]scheduling.Snapshot
+	errs      map[string]error
+	calls     map[string]int
+}
+
+func newFakeConsultor() *fakeConsultor {
+	return &fakeConsultor{
+		snapshots: make(map[string]scheduling.Snapshot),
+		errs:      make(map[string]error),
+		calls:     make(map[string]int),
+	}
+}
+
+func (c *fakeConsultor) ConsultarCarteira(ctx context.Context, job *scheduling.Job, item *scheduling.JobItem) (*scheduling.Snapshot, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.calls[item.Documento]++
+	if err := c.errs[item.Documento]; err != nil {
+		return nil, err
+	}
+	snapshot := c.snapshots[item.Documento]
+	snapshot.Restricoes = append([]scheduling.Restricao(nil), snapshot.Restricoes...)
+	return &snapshot, nil
+}
+
+func (c *fakeConsultor) set(documento string, score int, restricoes ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	snapshot := scheduling.Snapshot{Score: &score}
+	for _, id := range restricoes {
+		snapshot.Restricoes = append(snapshot.Restricoes, scheduling.Restricao{ID: id, Tipo: "inadimplencia", Valor: 500})
+	}
+	c.snapshots[documento] = snapshot
+}
+
+func (c *fakeConsultor) setError(documento string, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.errs[documento] = err
+}
+
+func (c *fakeConsultor) callCount(documento string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.calls[documento]
+}
+
+// fakeConsent aceita apenas os consentimentos registados
+type fakeConsent struct {
+	mutex sync.Mutex
+	valid map[string]bool
+}
+
+func (c *fakeConsent) VerificarConsentimento(ctx context.Context, market, documento, consentimentoID string) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.valid[consentimentoID], nil
+}
+
+func (c *fakeConsent) grant(consentimentoID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.valid[consentimentoID] = true
+}
+
+func (c *fakeConsent) expire(consentimentoID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.valid, consentimentoID)
+}
+
+type schedulerFixture struct {
+	scheduler *scheduling.Scheduler
+	consultor *fakeConsultor
+	consent   *fakeConsent
+	clock     *testClock
+}
+
+func newSchedulerFixture() *schedulerFixture {
+	f := &schedulerFixture{
+		consultor: newFakeConsultor(),
+		consent:   &fakeConsent{valid: map[string]bool{"consent-1": true, "consent-2": true}},
+		clock:     &testClock{now: time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)},
+	}
+	config := scheduling.DefaultConfig()
+	config.ConsentimentoObrigatorio = map[string]bool{"brazil": true, "usa": false}
+	f.scheduler = scheduling.NewScheduler(scheduling.NewInMemoryStore(0), f.consultor, f.consent, config)
+	f.scheduler.SetClock(f.clock.Now)
+	return f
+}
+
+func validJobRequest() scheduling.CreateJobRequest {
+	return scheduling.CreateJobRequest{
+		TenantID:   "credor-1",
+		Nome:       "Carteira crédito pessoal",
+		Market:     "Brazil",
+		Frequencia: scheduling.FrequenciaMensal,
+		Itens: []scheduling.ItemRequest{
+			{Documento: "123.456.789-00", Referencia: "CTR-1", ConsentimentoID: "consent-1"},
+			{Documento: "987.654.321-00", Referencia: "CTR-2", ConsentimentoID: "consent-2"},
+		},
+	}
+}
+
+// runAt executa um ciclo do agendador no instante indicado
+func (f *schedulerFixture) runAt(t *testing.T, now time.Time) int {
+	f.clock.Set(now)
+	executed, err := f.scheduler.RunOnce(context.Background())
+	require.NoError(t, err)
+	return executed
+}
+
+func (f *schedulerFixture) lastRun(t *testing.T, jobID string) *scheduling.Run {
+	runs, err := f.scheduler.ListRuns(context.Background(), "credor-1", jobID, 1)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	return runs[0]
+}
+
+func TestCreateJobValidation(t *testing.T) {
+	f := newSchedulerFixture()
+	ctx := context.Background()
+
+	t.Run("frequência desconhecida", func(t *testing.T) {
+		req := validJobRequest()
+		req.Frequencia = "HORARIA"
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.ErrorIs(t, err, scheduling.ErrInvalidJob)
+	})
+
+	t.Run("carteira vazia", func(t *testing.T) {
+		req := validJobRequest()
+		req.Itens = nil
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.ErrorIs(t, err, scheduling.ErrInvalidJob)
+	})
+
+	t.Run("documento repetido", func(t *testing.T) {
+		req := validJobRequest()
+		req.Itens[1].Documento = req.Itens[0].Documento
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.ErrorIs(t, err, scheduling.ErrInvalidJob)
+	})
+
+	t.Run("consentimento obrigatório no mercado", func(t *testing.T) {
+		req := validJobRequest()
+		req.Itens[1].ConsentimentoID = ""
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.ErrorIs(t, err, scheduling.ErrConsentRequired)
+		assert.NotContains(t, err.Error(), "987.654.321-00")
+	})
+
+	t.Run("consentimento inválido", func(t *testing.T) {
+		req := validJobRequest()
+		req.Itens[0].ConsentimentoID = "consent-desconhecido"
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.ErrorIs(t, err, scheduling.ErrConsentInvalid)
+	})
+
+	t.Run("mercado sem consentimento obrigatório", func(t *testing.T) {
+		req := validJobRequest()
+		req.Market = "USA"
+		req.Itens[0].ConsentimentoID = ""
+		_, err := f.scheduler.CreateJob(ctx, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("documentos mascarados", func(t *testing.T) {
+		job, err := f.scheduler.CreateJob(ctx, validJobRequest())
+		require.NoError(t, err)
+		assert.Equal(t, "************00", job.Itens[0].DocumentoMascarado)
+		assert.Equal(t, scheduling.StatusActive, job.Status)
+
+		encoded, err := json.Marshal(job)
+		require.NoError(t, err)
+		assert.NotContains(t, string(encoded), "123.456.789-00")
+	})
+}
+
+func TestScheduledRunsRecordDeltas(t *testing.T) {
+	f := newSchedulerFixture()
+	ctx := context.Background()
+	f.consultor.set("123.456.789-00", 700)
+	f.consultor.set("987.654.321-00", 650, "R1")
+
+	job, err := f.scheduler.CreateJob(ctx, validJobRequest())
+	require.NoError(t, err)
+
+	// Primeira execução: referência, sem deltas
+	assert.Equal(t, 1, f.runAt(t, f.clock.Now()))
+	first := f.lastRun(t, job.ID)
+	assert.Equal(t, scheduling.RunCompleted, first.Status)
+	assert.Equal(t, 2, first.Consultados)
+	assert.Empty(t, first.Deltas)
+
+	job, err = f.scheduler.GetJob(ctx, "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 2, 15, 6, 0, 0, 0, time.UTC), job.NextRunAt)
+
+	// Antes do mês seguinte nada é executado
+	assert.Equal(t, 0, f.runAt(t, time.Date(2025, 2, 14, 6, 0, 0, 0, time.UTC)))
+
+	f.consultor.set("123.456.789-00", 640, "R9")
+	f.consultor.set("987.654.321-00", 650)
+	assert.Equal(t, 1, f.runAt(t, time.Date(2025, 2, 15, 6, 0, 0, 0, time.UTC)))
+
+	second := f.lastRun(t, job.ID)
+	assert.Equal(t, scheduling.RunCompleted, second.Status)
+	require.Len(t, second.Deltas, 2)
+
+	byReferencia := map[string]scheduling.Delta{}
+	for _, delta := range second.Deltas {
+		byReferencia[delta.Referencia] = delta
+	}
+	piorou := byReferencia["CTR-1"]
+	require.NotNil(t, piorou.ScoreAnterior)
+	assert.Equal(t, 700, *piorou.ScoreAnterior)
+	assert.Equal(t, 640, *piorou.ScoreAtual)
+	assert.Equal(t, -60, piorou.Variacao)
+	require.Len(t, piorou.NovasRestricoes, 1)
+	assert.Equal(t, "R9", piorou.NovasRestricoes[0].ID)
+	assert.Equal(t, "************00", piorou.Documento)
+
+	regularizou := byReferencia["CTR-2"]
+	assert.Nil(t, regularizou.ScoreAnterior)
+	assert.Equal(t, []string{"R1"}, regularizou.RestricoesRegularizadas)
+
+	// Sem alterações, a execução seguinte não tem deltas
+	f.runAt(t, time.Date(2025, 3, 15, 6, 0, 0, 0, time.UTC))
+	assert.Empty(t, f.lastRun(t, job.ID).Deltas)
+
+	runs, err := f.scheduler.ListRuns(ctx, "credor-1", job.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, runs, 3)
+
+	run, err := f.scheduler.GetRun(ctx, "credor-1", job.ID, second.ID)
+	require.NoError(t, err)
+	assert.Len(t, run.Deltas, 2)
+}
+
+func TestMissedRunsAreNotRepeated(t *testing.T) {
+	f := newSchedulerFixture()
+	req := validJobRequest()
+	req.Frequencia = scheduling.FrequenciaSemanal
+	job, err := f.scheduler.CreateJob(context.Background(), req)
+	require.NoError(t, err)
+
+	f.runAt(t, f.clock.Now())
+	// Serviço parado durante três semanas: uma única execução, e a seguinte na semana seguinte
+	f.runAt(t, time.Date(2025, 2, 6, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, 0, f.runAt(t, time.Date(2025, 2, 6, 10, 0, 0, 0, time.UTC)))
+
+	job, err = f.scheduler.GetJob(context.Background(), "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 2, 12, 6, 0, 0, 0, time.UTC), job.NextRunAt)
+	assert.Equal(t, 2, f.consultor.callCount("123.456.789-00"))
+}
+
+func TestExpiredConsentPausesItemAndJob(t *testing.T) {
+	f := newSchedulerFixture()
+	ctx := context.Background()
+	job, err := f.scheduler.CreateJob(ctx, validJobRequest())
+	require.NoError(t, err)
+	f.runAt(t, f.clock.Now())
+
+	// Um consentimento expira: o documento é pausado e não é consultado
+	f.consent.expire("consent-2")
+	f.runAt(t, time.Date(2025, 2, 15, 6, 0, 0, 0, time.UTC))
+	run := f.lastRun(t, job.ID)
+	assert.Equal(t, scheduling.RunPartial, run.Status)
+	assert.Equal(t, 1, run.Consultados)
+	assert.Equal(t, 1, run.SemConsentimento)
+	assert.Equal(t, 1, f.consultor.callCount("987.654.321-00"))
+
+	job, err = f.scheduler.GetJob(ctx, "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduling.StatusActive, job.Status)
+	assert.Equal(t, scheduling.StatusPaused, job.Itens[1].Status)
+	assert.NotEmpty(t, job.Itens[1].MotivoPausa)
+
+	// Sem nenhum consentimento válido, o job é pausado sem consultas
+	f.consent.expire("consent-1")
+	f.runAt(t, time.Date(2025, 3, 15, 6, 0, 0, 0, time.UTC))
+	run = f.lastRun(t, job.ID)
+	assert.Equal(t, scheduling.RunPaused, run.Status)
+	assert.Equal(t, 0, run.Consultados)
+	assert.Equal(t, 2, f.consultor.callCount("123.456.789-00"))
+
+	job, err = f.scheduler.GetJob(ctx, "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduling.StatusPaused, job.Status)
+	assert.Equal(t, 0, f.runAt(t, time.Date(2025, 4, 15, 6, 0, 0, 0, time.UTC)))
+
+	// A retoma exige um consentimento válido; com o consentimento renovado o documento é reativado
+	_, err = f.scheduler.ResumeJob(ctx, "credor-1", job.ID, scheduling.ResumeRequest{})
+	assert.ErrorIs(t, err, scheduling.ErrConsentInvalid)
+
+	f.consent.grant("consent-3")
+	job, err = f.scheduler.ResumeJob(ctx, "credor-1", job.ID, scheduling.ResumeRequest{
+		Consentimentos: map[string]string{job.Itens[1].ID: "consent-3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, scheduling.StatusActive, job.Status)
+	assert.Equal(t, scheduling.StatusPaused, job.Itens[0].Status)
+	assert.Equal(t, scheduling.StatusActive, job.Itens[1].Status)
+
+	// A execução em atraso é feita no ciclo seguinte
+	assert.Equal(t, 1, f.runAt(t, time.Date(2025, 4, 15, 6, 1, 0, 0, time.UTC)))
+	assert.Equal(t, 2, f.consultor.callCount("987.654.321-00"))
+}
+
+func TestConsultaFailuresAreRecorded(t *testing.T) {
+	f := newSchedulerFixture()
+	job, err := f.scheduler.CreateJob(context.Background(), validJobRequest())
+	require.NoError(t, err)
+
+	f.consultor.setError("987.654.321-00", errors.New("fornecedor indisponível"))
+	f.runAt(t, f.clock.Now())
+	run := f.lastRun(t, job.ID)
+	assert.Equal(t, scheduling.RunPartial, run.Status)
+	assert.Equal(t, 1, run.Falhas)
+
+	f.consultor.setError("123.456.789-00", errors.New("fornecedor indisponível"))
+	f.runAt(t, time.Date(2025, 2, 15, 6, 0, 0, 0, time.UTC))
+	assert.Equal(t, scheduling.RunFailed, f.lastRun(t, job.ID).Status)
+
+	job, err = f.scheduler.GetJob(context.Background(), "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduling.StatusActive, job.Status)
+	assert.Equal(t, "fornecedor indisponível", job.Itens[1].UltimoErro)
+}
+
+func TestPausedJobIsNotExecuted(t *testing.T) {
+	f := newSchedulerFixture()
+	ctx := context.Background()
+	job, err := f.scheduler.CreateJob(ctx, validJobRequest())
+	require.NoError(t, err)
+
+	_, err = f.scheduler.PauseJob(ctx, "credor-1", job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, f.runAt(t, f.clock.Now()))
+
+	_, err = f.scheduler.ResumeJob(ctx, "credor-1", job.ID, scheduling.ResumeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.runAt(t, f.clock.Now()))
+
+	require.NoError(t, f.scheduler.DeleteJob(ctx, "credor-1", job.ID))
+	_, err = f.scheduler.ListRuns(ctx, "credor-1", job.ID, 0)
+	assert.ErrorIs(t, err, scheduling.ErrJobNotFound)
+}
+
+func TestRunHistoryIsBounded(t *testing.T) {
+	store := scheduling.NewInMemoryStore(2)
+	ctx := context.Background()
+	require.NoError(t, store.SaveJob(ctx, &scheduling.Job{ID: "job-1", TenantID: "credor-1"}))
+	for _, id := range []string{"run-1", "run-2", "run-3"} {
+		require.NoError(t, store.SaveRun(ctx, &scheduling.Run{ID: id, JobID: "job-1", TenantID: "credor-1"}))
+	}
+
+	runs, err := store.ListRuns(ctx, "credor-1", "job-1", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "run-3", runs[0].ID)
+	assert.Equal(t, "run-2", runs[1].ID)
+
+	_, err = store.GetRun(ctx, "credor-1", "job-1", "run-1")
+	assert.ErrorIs(t, err, scheduling.ErrRunNotFound)
+	_, err = store.GetRun(ctx, "outro-credor", "job-1", "run-3")
+	assert.ErrorIs(t, err, scheduling.ErrRunNotFound)
+}
+
+func TestScheduledJobsAPI(t *testing.T) {
+	f := newSchedulerFixture()
+	mux := http.NewServeMux()
+	scheduling.NewHandler(f.scheduler).Register(mux)
+
+	do := func(method, path, tenantID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if tenantID != "" {
+			req.Header.Set(scheduling.TenantHeader, tenantID)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"nome":"Carteira PME","market":"brazil","frequencia":"MENSAL","inicio":"2025-02-01T06:00:00Z",` +
+		`"itens":[{"documento":"12.345.678/0001-90","tipoEntidade":"PJ","consentimentoId":"consent-1"}]}`
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/scheduled-jobs", "", body).Code)
+
+	rec := do(http.MethodPost, "/scheduled-jobs", "credor-1", body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var job scheduling.Job
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, time.Date(2025, 2, 1, 6, 0, 0, 0, time.UTC), job.NextRunAt)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/scheduled-jobs/"+job.ID, "outro-credor", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/scheduled-jobs/"+job.ID+"/pause", "credor-1", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/scheduled-jobs/"+job.ID+"/resume", "credor-1", "").Code)
+
+	f.runAt(t, time.Date(2025, 2, 1, 6, 0, 0, 0, time.UTC))
+	rec = do(http.MethodGet, "/scheduled-jobs/"+job.ID+"/runs?limit=5", "credor-1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runs []scheduling.Run
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&runs))
+	require.Len(t, runs, 1)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/scheduled-jobs/"+job.ID+"/runs/"+runs[0].ID, "credor-1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/scheduled-jobs/"+job.ID+"/runs/desconhecida", "credor-1", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/scheduled-jobs/"+job.ID+"/runs?limit=x", "credor-1", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/scheduled-jobs/"+job.ID, "credor-1", "").Code)
+
+	body = `{"nome":"Carteira","market":"brazil","frequencia":"MENSAL","itens":[{"documento":"123"}]}`
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/scheduled-jobs", "credor-1", body).Code)
+	body = `{"nome":"Carteira","market":"brazil","frequencia":"ANUAL","itens":[{"documento":"123"}]}`
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/scheduled-jobs", "credor-1", body).Code)
+}
@@ -0,0 +1,79 @@
+/**
+ * @file fetcher.go
+ * @description Obtenção do score diretamente nos adaptadores dos provedores de crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"innovabiz/iam/src/bureau-credito/adapters"
+)
+
+// Escala do CreditScore dos adaptadores de provedores de crédito
+const (
+	ProviderScoreMin = 0
+	ProviderScoreMax = 999
+)
+
+// RequestReasonScore identifica nos provedores as consultas feitas apenas para obter o score
+const RequestReasonScore = "SCORE"
+
+// ProviderFetcher obtém o score num adaptador de provedor de crédito, sem passar pelo orquestrador
+type ProviderFetcher struct {
+	provider adapters.CreditProvider
+	name     string
+}
+
+// NewProviderFetcher cria um Fetcher para o adaptador do provedor
+func NewProviderFetcher(provider adapters.CreditProvider) *ProviderFetcher {
+	return &ProviderFetcher{
+		provider: provider,
+		name:     provider.GetProviderInfo().ID,
+	}
+}
+
+// Name retorna o identificador do provedor
+func (f *ProviderFetcher) Name() string {
+	return f.name
+}
+
+// FetchScore consulta o relatório de crédito do documento com prioridade crítica e retorna o seu score
+func (f *ProviderFetcher) FetchScore(ctx context.Context, request ScoreRequest) (RawScore, error) {
+	response, err := f.provider.GetCreditReport(ctx, adapters.CreditReportRequest{
+		DocumentNumber:  request.DocumentNumber,
+		DocumentType:    request.DocumentType,
+		TenantID:        request.TenantID,
+		RequestReason:   RequestReasonScore,
+		RequestPriority: adapters.PriorityCritical,
+	})
+	if err != nil {
+		return RawScore{}, err
+	}
+	if response == nil {
+		return RawScore{}, fmt.Errorf("resposta vazia do provedor %s", f.name)
+	}
+	if response.Error != nil {
+		return RawScore{}, fmt.Errorf("erro do provedor %s: %s - %s", f.name, response.Error.Code, response.Error.Message)
+	}
+
+	scoredAt := response.ReportDate
+	if response.IsFromCache && response.CacheTimestamp != nil {
+		scoredAt = *response.CacheTimestamp
+	}
+	if scoredAt.IsZero() {
+		scoredAt = time.Now()
+	}
+	return RawScore{
+		Provider: f.name,
+		Value:    response.CreditScore,
+		Min:      ProviderScoreMin,
+		Max:      ProviderScoreMax,
+		ScoredAt: scoredAt,
+	}, nil
+}
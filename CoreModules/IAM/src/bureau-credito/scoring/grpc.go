@@ -0,0 +1,158 @@
+/**
+ * @file grpc.go
+ * @description Serviço gRPC ScoreService para consulta do score em caminhos de decisão em tempo real
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ScoreServiceName é o nome completo do serviço gRPC
+const ScoreServiceName = "innovabiz.bureaucredito.v1.ScoreService"
+
+// CodecName é o subtipo de conteúdo das mensagens do ScoreService (application/grpc+json)
+// As mensagens são as structs ScoreRequest e ScoreResponse serializadas em JSON, sem código gerado
+const CodecName = "json"
+
+// TenantMetadataKey é a chave de metadados usada quando o pedido não indica o tenant
+const TenantMetadataKey = "x-tenant-id"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec serializa as mensagens do ScoreService em JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+// ScoreServiceServer é a interface do servidor do ScoreService
+type ScoreServiceServer interface {
+	// GetScore retorna o score normalizado de um documento
+	GetScore(ctx context.Context, request *ScoreRequest) (*ScoreResponse, error)
+}
+
+// ScoreServiceDesc descreve o ScoreService para registo num servidor gRPC
+var ScoreServiceDesc = grpc.ServiceDesc{
+	ServiceName: ScoreServiceName,
+	HandlerType: (*ScoreServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetScore",
+			Handler:    getScoreHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// RegisterScoreServiceServer regista o ScoreService no servidor gRPC
+func RegisterScoreServiceServer(registrar grpc.ServiceRegistrar, server ScoreServiceServer) {
+	registrar.RegisterService(&ScoreServiceDesc, server)
+}
+
+func getScoreHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := new(ScoreRequest)
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScoreServiceServer).GetScore(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + ScoreServiceName + "/GetScore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScoreServiceServer).GetScore(ctx, req.(*ScoreRequest))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// GRPCServer implementa o ScoreService sobre o Service
+type GRPCServer struct {
+	service *Service
+}
+
+// NewGRPCServer cria o servidor do ScoreService
+func NewGRPCServer(service *Service) *GRPCServer {
+	return &GRPCServer{service: service}
+}
+
+// GetScore retorna o score do documento; o prazo do pedido gRPC é o prazo da consulta
+func (s *GRPCServer) GetScore(ctx context.Context, request *ScoreRequest) (*ScoreResponse, error) {
+	if request.TenantID == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(TenantMetadataKey); len(values) > 0 {
+				request.TenantID = values[0]
+			}
+		}
+	}
+
+	response, err := s.service.Score(ctx, *request)
+	if err != nil {
+		return nil, statusFromError(err)
+	}
+	return response, nil
+}
+
+// statusFromError converte os erros do Service em estados gRPC
+func statusFromError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrDeadlineTooShort), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, ErrScoreUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// ScoreServiceClient é o cliente do ScoreService
+type ScoreServiceClient interface {
+	// GetScore retorna o score normalizado de um documento
+	GetScore(ctx context.Context, request *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error)
+}
+
+type scoreServiceClient struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewScoreServiceClient cria o cliente do ScoreService sobre a ligação gRPC
+func NewScoreServiceClient(conn grpc.ClientConnInterface) ScoreServiceClient {
+	return &scoreServiceClient{conn: conn}
+}
+
+func (c *scoreServiceClient) GetScore(ctx context.Context, request *ScoreRequest, opts ...grpc.CallOption) (*ScoreResponse, error) {
+	response := new(ScoreResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	if err := c.conn.Invoke(ctx, "/"+ScoreServiceName+"/GetScore", request, response, opts...); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
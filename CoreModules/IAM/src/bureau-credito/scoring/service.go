@@ -0,0 +1,426 @@
+/**
+ * @file service.go
+ * @description Consulta rápida do score de crédito para decisões em tempo real, com cache por documento,
+ *              prazos estritos e fallbacks, sem passar pelo pipeline completo de consultas
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package scoring
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/innovabiz/iam/resilience"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Origem do score devolvido a um pedido
+const (
+	SourceCache    = "cache"    // Score em cache dentro da idade aceite
+	SourceProvider = "provider" // Obtido do provedor principal
+	SourceFallback = "fallback" // Obtido de um provedor alternativo após falha do principal
+	SourceStale    = "stale"    // Score em cache expirado, devolvido por falha dos provedores ou falta de prazo
+)
+
+// Resultados registados nas métricas além das origens do score
+const (
+	OutcomeDeadline    = "deadline"    // Prazo esgotado sem score disponível
+	OutcomeUnavailable = "unavailable" // Provedores falharam sem score em cache
+	OutcomeInvalid     = "invalid"     // Pedido inválido
+)
+
+// ScoreScaleMax é o limite superior da escala normalizada (0 a 1000)
+const ScoreScaleMax = 1000
+
+// Valores padrão do serviço de score
+const (
+	DefaultTTL             = 15 * time.Minute
+	DefaultStaleTTL        = 24 * time.Hour
+	DefaultMaxEntries      = 100000
+	DefaultTimeout         = 50 * time.Millisecond
+	DefaultMinBudget       = 5 * time.Millisecond
+	DefaultResponseReserve = 2 * time.Millisecond
+)
+
+var (
+	// ErrInvalidRequest indica um pedido sem tenant ou documento
+	ErrInvalidRequest = errors.New("tenant e documento são obrigatórios")
+
+	// ErrDeadlineTooShort indica que o prazo do pedido não chega para consultar um provedor
+	// e não há score em cache para devolver
+	ErrDeadlineTooShort = errors.New("prazo do pedido insuficiente para obter o score")
+
+	// ErrScoreUnavailable indica que nenhum provedor devolveu o score e não há score em cache
+	ErrScoreUnavailable = errors.New("score indisponível nos provedores e sem valor em cache")
+)
+
+// Config define o serviço de score
+type Config struct {
+	// TTL é a idade máxima de um score em cache devolvido sem consultar o provedor
+	TTL time.Duration `json:"ttl"`
+
+	// StaleTTL é o período após o TTL em que o score expirado ainda serve de fallback; zero desativa
+	StaleTTL time.Duration `json:"staleTtl"`
+
+	// MaxEntries limita os scores mantidos em memória; os menos usados são descartados primeiro
+	MaxEntries int `json:"maxEntries"`
+
+	// DefaultTimeout é o prazo aplicado aos pedidos que chegam sem prazo
+	DefaultTimeout time.Duration `json:"defaultTimeout"`
+
+	// MinBudget é o prazo restante mínimo para consultar um provedor
+	MinBudget time.Duration `json:"minBudget"`
+
+	// ResponseReserve é a parte do prazo reservada para responder depois da consulta ao provedor
+	ResponseReserve time.Duration `json:"responseReserve"`
+}
+
+// DefaultConfig retorna a configuração padrão do serviço de score
+func DefaultConfig() Config {
+	return Config{
+		TTL:             DefaultTTL,
+		StaleTTL:        DefaultStaleTTL,
+		MaxEntries:      DefaultMaxEntries,
+		DefaultTimeout:  DefaultTimeout,
+		MinBudget:       DefaultMinBudget,
+		ResponseReserve: DefaultResponseReserve,
+	}
+}
+
+// ScoreRequest é o pedido de score de um documento
+type ScoreRequest struct {
+	TenantID       string `json:"tenantId"`
+	DocumentNumber string `json:"documentNumber"`
+	DocumentType   string `json:"documentType,omitempty"` // CPF, CNPJ, NIF, etc.
+	Market         string `json:"market,omitempty"`
+
+	// MaxAgeSeconds reduz a idade aceite do score em cache; zero usa o TTL do serviço
+	MaxAgeSeconds int64 `json:"maxAgeSeconds,omitempty"`
+
+	// RejectStale impede que um score expirado seja devolvido como fallback
+	RejectStale bool `json:"rejectStale,omitempty"`
+}
+
+// ScoreResponse é o score normalizado de um documento
+type ScoreResponse struct {
+	Score            int       `json:"score"`    // 0-1000
+	RawScore         int       `json:"rawScore"` // Na escala do provedor
+	Provider         string    `json:"provider"`
+	Source           string    `json:"source"`
+	Stale            bool      `json:"stale"`
+	ScoredAt         time.Time `json:"scoredAt"`
+	ProcessingTimeMs int64     `json:"processingTimeMs"`
+}
+
+// RawScore é o score devolvido por um provedor, na escala [Min, Max] do provedor
+type RawScore struct {
+	Provider string
+	Value    int
+	Min      int
+	Max      int
+	ScoredAt time.Time
+}
+
+// Fetcher obtém o score de um documento num provedor
+type Fetcher interface {
+	// Name identifica o provedor nas respostas e nos erros
+	Name() string
+
+	// FetchScore consulta o score; deve respeitar o prazo do contexto
+	FetchScore(ctx context.Context, request ScoreRequest) (RawScore, error)
+}
+
+// Normalize converte o score da escala do provedor para a escala 0-1000
+// Valores fora da escala são limitados aos seus extremos
+func Normalize(raw RawScore) (int, error) {
+	if raw.Max <= raw.Min {
+		return 0, fmt.Errorf("escala de score inválida do provedor %s: [%d, %d]", raw.Provider, raw.Min, raw.Max)
+	}
+	value := raw.Value
+	if value < raw.Min {
+		value = raw.Min
+	}
+	if value > raw.Max {
+		value = raw.Max
+	}
+	return int(math.Round(float64(value-raw.Min) * ScoreScaleMax / float64(raw.Max-raw.Min))), nil
+}
+
+// entry é o score normalizado de um documento guardado em cache
+type entry struct {
+	key      string
+	score    int
+	raw      int
+	provider string
+	scoredAt time.Time
+	storedAt time.Time
+}
+
+// Service devolve o score de um documento a partir da cache ou, quando expirado, dos provedores
+// por ordem, dentro do prazo do pedido. Esgotados os provedores ou o prazo, devolve o score
+// expirado mais recente ainda dentro do StaleTTL
+type Service struct {
+	config   Config
+	fetchers []Fetcher
+	metrics  *Metrics
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// NewService cria o serviço de score; o primeiro fetcher é o provedor principal
+// e os restantes são os fallbacks, consultados pela ordem indicada
+func NewService(config Config, fetchers ...Fetcher) *Service {
+	if config.TTL <= 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.StaleTTL < 0 {
+		config.StaleTTL = 0
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultMaxEntries
+	}
+	if config.DefaultTimeout <= 0 {
+		config.DefaultTimeout = DefaultTimeout
+	}
+	if config.MinBudget <= 0 {
+		config.MinBudget = DefaultMinBudget
+	}
+	if config.ResponseReserve < 0 {
+		config.ResponseReserve = 0
+	}
+	return &Service{
+		config:   config,
+		fetchers: fetchers,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// SetClock substitui o relógio usado na idade dos scores em cache
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetMetrics define as métricas Prometheus dos pedidos de score
+func (s *Service) SetMetrics(metrics *Metrics) {
+	s.metrics = metrics
+}
+
+// Score retorna o score normalizado do documento
+func (s *Service) Score(ctx context.Context, request ScoreRequest) (*ScoreResponse, error) {
+	startedAt := time.Now()
+
+	if strings.TrimSpace(request.TenantID) == "" || strings.TrimSpace(request.DocumentNumber) == "" {
+		s.metrics.observe(OutcomeInvalid, time.Since(startedAt))
+		return nil, ErrInvalidRequest
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = resilience.WithBudget(ctx, s.config.DefaultTimeout)
+		defer cancel()
+	}
+
+	key := documentKey(request)
+	cached, found := s.lookup(key)
+
+	maxAge := s.config.TTL
+	if request.MaxAgeSeconds > 0 && time.Duration(request.MaxAgeSeconds)*time.Second < maxAge {
+		maxAge = time.Duration(request.MaxAgeSeconds) * time.Second
+	}
+	if found && s.now().Sub(cached.storedAt) < maxAge {
+		return s.respond(cached, SourceCache, startedAt), nil
+	}
+
+	var lastErr error
+	for i, fetcher := range s.fetchers {
+		budget, ok := s.fetchBudget(ctx)
+		if !ok {
+			break
+		}
+
+		fetchCtx, cancel := resilience.WithBudget(ctx, budget)
+		raw, err := fetcher.FetchScore(fetchCtx, request)
+		cancel()
+		if err == nil {
+			var score int
+			if score, err = Normalize(raw); err == nil {
+				fresh := s.store(key, raw, score)
+				source := SourceProvider
+				if i > 0 {
+					source = SourceFallback
+				}
+				return s.respond(fresh, source, startedAt), nil
+			}
+		}
+		lastErr = fmt.Errorf("%s: %w", fetcher.Name(), err)
+	}
+
+	if found && !request.RejectStale && s.now().Sub(cached.storedAt) < s.config.TTL+s.config.StaleTTL {
+		return s.respond(cached, SourceStale, startedAt), nil
+	}
+
+	// Sem prazo restante para consultar um provedor, a falha é reportada como prazo esgotado
+	if _, ok := s.fetchBudget(ctx); !ok {
+		s.metrics.observe(OutcomeDeadline, time.Since(startedAt))
+		if lastErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDeadlineTooShort, lastErr)
+		}
+		return nil, ErrDeadlineTooShort
+	}
+	s.metrics.observe(OutcomeUnavailable, time.Since(startedAt))
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScoreUnavailable, lastErr)
+	}
+	return nil, ErrScoreUnavailable
+}
+
+// Invalidate descarta o score em cache do documento, forçando o próximo pedido a consultar o provedor
+func (s *Service) Invalidate(tenantID, documentType, documentNumber string) {
+	key := documentKey(ScoreRequest{TenantID: tenantID, DocumentType: documentType, DocumentNumber: documentNumber})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, exists := s.entries[key]; exists {
+		s.lru.Remove(element)
+		delete(s.entries, key)
+	}
+}
+
+// fetchBudget retorna o prazo de uma consulta ao provedor, descontada a reserva para responder
+// ok é false quando o prazo restante fica abaixo do mínimo
+func (s *Service) fetchBudget(ctx context.Context) (time.Duration, bool) {
+	remaining, _ := resilience.Remaining(ctx)
+	budget := remaining - s.config.ResponseReserve
+	return budget, budget >= s.config.MinBudget
+}
+
+// respond cria a resposta a partir de um score em cache e regista a métrica da origem
+func (s *Service) respond(cached entry, source string, startedAt time.Time) *ScoreResponse {
+	elapsed := time.Since(startedAt)
+	s.metrics.observe(source, elapsed)
+	return &ScoreResponse{
+		Score:            cached.score,
+		RawScore:         cached.raw,
+		Provider:         cached.provider,
+		Source:           source,
+		Stale:            source == SourceStale,
+		ScoredAt:         cached.scoredAt,
+		ProcessingTimeMs: elapsed.Milliseconds(),
+	}
+}
+
+// lookup retorna uma cópia do score em cache do documento, marcando-o como usado
+func (s *Service) lookup(key string) (entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, exists := s.entries[key]
+	if !exists {
+		return entry{}, false
+	}
+	s.lru.MoveToFront(element)
+	return *element.Value.(*entry), true
+}
+
+// store guarda o score normalizado do documento, descartando o menos usado quando o limite é atingido
+func (s *Service) store(key string, raw RawScore, score int) entry {
+	fresh := entry{
+		key:      key,
+		score:    score,
+		raw:      raw.Value,
+		provider: raw.Provider,
+		scoredAt: raw.ScoredAt,
+		storedAt: s.now(),
+	}
+	if fresh.scoredAt.IsZero() {
+		fresh.scoredAt = fresh.storedAt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, exists := s.entries[key]; exists {
+		*element.Value.(*entry) = fresh
+		s.lru.MoveToFront(element)
+		return fresh
+	}
+	for s.lru.Len() >= s.config.MaxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*entry).key)
+	}
+	stored := fresh
+	s.entries[key] = s.lru.PushFront(&stored)
+	return fresh
+}
+
+// documentKey resume o tenant e o documento sem manter o documento em claro na memória
+func documentKey(request ScoreRequest) string {
+	document := strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-', '/', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(request.DocumentNumber))
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		request.TenantID, strings.ToUpper(request.DocumentType), document,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Metrics contém as métricas Prometheus dos pedidos de score
+type Metrics struct {
+	requestsCounter   *prometheus.CounterVec
+	durationHistogram *prometheus.HistogramVec
+}
+
+// NewMetrics cria e regista as métricas do serviço de score
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		requestsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_score_requests_total",
+				Help: "Número total de pedidos de score pelo resultado (cache, provider, fallback, stale, deadline, unavailable, invalid)",
+			},
+			[]string{"outcome"},
+		),
+		durationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "bureau_credito_score_duration_seconds",
+				Help:    "Duração dos pedidos de score pelo resultado",
+				Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.02, 0.03, 0.05, 0.1, 0.25},
+			},
+			[]string{"outcome"},
+		),
+	}
+
+	registry.MustRegister(m.requestsCounter, m.durationHistogram)
+	return m
+}
+
+// observe regista o resultado e a duração de um pedido de score
+func (m *Metrics) observe(outcome string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requestsCounter.WithLabelValues(outcome).Inc()
+	m.durationHistogram.WithLabelValues(outcome).Observe(elapsed.Seconds())
+}
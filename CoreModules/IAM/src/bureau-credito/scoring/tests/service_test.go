@@ -0,0 +1,307 @@
+/**
+ * @file service_test.go
+ * @description Testes da consulta rápida do score de crédito e do ScoreService gRPC
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"innovabiz/iam/src/bureau-credito/scoring"
+)
+
+// testClock é um relógio ajustável para os testes da idade dos scores
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeFetcher devolve um score fixo ou um erro e conta as consultas
+type fakeFetcher struct {
+	name  string
+	value int
+	err   error
+	delay time.Duration
+	calls int32
+}
+
+func (f *fakeFetcher) Name() string {
+	return f.name
+}
+
+func (f *fakeFetcher) FetchScore(ctx context.Context, request scoring.ScoreRequest) (scoring.RawScore, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return scoring.RawScore{}, ctx.Err()
+		}
+	}
+	if f.err != nil {
+		return scoring.RawScore{}, f.err
+	}
+	return scoring.RawScore{Provider: f.name, Value: f.value, Min: 0, Max: 999}, nil
+}
+
+func (f *fakeFetcher) Calls() int {
+	return int(atomic.LoadInt32(&f.calls))
+}
+
+func newService(config scoring.Config, fetchers ...scoring.Fetcher) (*scoring.Service, *testClock) {
+	clock := &testClock{now: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}
+	service := scoring.NewService(config, fetchers...)
+	service.SetClock(clock.Now)
+	return service, clock
+}
+
+var scoreRequest = scoring.ScoreRequest{
+	TenantID:       "tenant-1",
+	DocumentNumber: "123.456.789-00",
+	DocumentType:   "CPF",
+}
+
+func TestNormalize(t *testing.T) {
+	score, err := scoring.Normalize(scoring.RawScore{Value: 999, Min: 0, Max: 999})
+	require.NoError(t, err)
+	assert.Equal(t, 1000, score)
+
+	score, err = scoring.Normalize(scoring.RawScore{Value: 50, Min: 0, Max: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 500, score)
+
+	score, err = scoring.Normalize(scoring.RawScore{Value: 1200, Min: 300, Max: 850})
+	require.NoError(t, err)
+	assert.Equal(t, 1000, score, "valores fora da escala são limitados ao máximo")
+
+	_, err = scoring.Normalize(scoring.RawScore{Provider: "X", Value: 10, Min: 100, Max: 100})
+	assert.Error(t, err)
+}
+
+func TestScoreFetchedAndCachedByDocument(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 999}
+	service, _ := newService(scoring.DefaultConfig(), primary)
+
+	response, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, response.Score)
+	assert.Equal(t, 999, response.RawScore)
+	assert.Equal(t, "SERASA", response.Provider)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+
+	// O mesmo documento sem formatação partilha a entrada em cache
+	unformatted := scoreRequest
+	unformatted.DocumentNumber = "12345678900"
+	response, err = service.Score(context.Background(), unformatted)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceCache, response.Source)
+	assert.Equal(t, 1000, response.Score)
+	assert.Equal(t, 1, primary.Calls())
+
+	// Outro tenant não reutiliza o score
+	otherTenant := scoreRequest
+	otherTenant.TenantID = "tenant-2"
+	response, err = service.Score(context.Background(), otherTenant)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+	assert.Equal(t, 2, primary.Calls())
+}
+
+func TestMaxAgeForcesProviderLookup(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 500}
+	service, clock := newService(scoring.DefaultConfig(), primary)
+
+	_, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Minute)
+	request := scoreRequest
+	request.MaxAgeSeconds = 60
+	response, err := service.Score(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+	assert.Equal(t, 2, primary.Calls())
+}
+
+func TestFallbackProviderOnPrimaryFailure(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", err: errors.New("indisponível")}
+	fallback := &fakeFetcher{name: "SPC", value: 600}
+	service, _ := newService(scoring.DefaultConfig(), primary, fallback)
+
+	response, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceFallback, response.Source)
+	assert.Equal(t, "SPC", response.Provider)
+	assert.Equal(t, 1, primary.Calls())
+	assert.Equal(t, 1, fallback.Calls())
+}
+
+func TestStaleScoreServedWhenProvidersFail(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700}
+	config := scoring.DefaultConfig()
+	config.TTL = time.Minute
+	config.StaleTTL = time.Hour
+	service, clock := newService(config, primary)
+
+	_, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+
+	clock.Advance(10 * time.Minute)
+	primary.err = errors.New("indisponível")
+	response, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceStale, response.Source)
+	assert.True(t, response.Stale)
+	assert.Equal(t, 701, response.Score)
+
+	request := scoreRequest
+	request.RejectStale = true
+	_, err = service.Score(context.Background(), request)
+	assert.ErrorIs(t, err, scoring.ErrScoreUnavailable)
+
+	// Fora do StaleTTL o score expirado deixa de servir de fallback
+	clock.Advance(time.Hour)
+	_, err = service.Score(context.Background(), scoreRequest)
+	assert.ErrorIs(t, err, scoring.ErrScoreUnavailable)
+}
+
+func TestDeadlineTooShortSkipsProviders(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700}
+	config := scoring.DefaultConfig()
+	config.MinBudget = 20 * time.Millisecond
+	service, _ := newService(config, primary)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := service.Score(ctx, scoreRequest)
+	assert.ErrorIs(t, err, scoring.ErrDeadlineTooShort)
+	assert.Equal(t, 0, primary.Calls())
+}
+
+func TestSlowProviderBoundedByDeadline(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700, delay: time.Second}
+	fallback := &fakeFetcher{name: "SPC", value: 600}
+	service, _ := newService(scoring.DefaultConfig(), primary, fallback)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	startedAt := time.Now()
+	_, err := service.Score(ctx, scoreRequest)
+	assert.ErrorIs(t, err, scoring.ErrDeadlineTooShort)
+	assert.Less(t, time.Since(startedAt), 100*time.Millisecond)
+	assert.Equal(t, 0, fallback.Calls(), "sem prazo restante o fallback não é consultado")
+}
+
+func TestDefaultTimeoutAppliedWithoutDeadline(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700, delay: time.Second}
+	config := scoring.DefaultConfig()
+	config.DefaultTimeout = 20 * time.Millisecond
+	service, _ := newService(config, primary)
+
+	startedAt := time.Now()
+	_, err := service.Score(context.Background(), scoreRequest)
+	assert.ErrorIs(t, err, scoring.ErrDeadlineTooShort)
+	assert.Less(t, time.Since(startedAt), 100*time.Millisecond)
+}
+
+func TestLeastRecentlyUsedScoreEvicted(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700}
+	config := scoring.DefaultConfig()
+	config.MaxEntries = 1
+	service, _ := newService(config, primary)
+
+	other := scoreRequest
+	other.DocumentNumber = "987.654.321-00"
+
+	_, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	_, err = service.Score(context.Background(), other)
+	require.NoError(t, err)
+
+	response, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+	assert.Equal(t, 3, primary.Calls())
+}
+
+func TestInvalidateDiscardsCachedScore(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 700}
+	service, _ := newService(scoring.DefaultConfig(), primary)
+
+	_, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	service.Invalidate("tenant-1", "cpf", "12345678900")
+
+	response, err := service.Score(context.Background(), scoreRequest)
+	require.NoError(t, err)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+	assert.Equal(t, 2, primary.Calls())
+}
+
+func TestScoreServiceOverGRPC(t *testing.T) {
+	primary := &fakeFetcher{name: "SERASA", value: 999}
+	service, _ := newService(scoring.DefaultConfig(), primary)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	scoring.RegisterScoreServiceServer(server, scoring.NewGRPCServer(service))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := scoring.NewScoreServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Tenant indicado nos metadados do pedido
+	ctx = metadata.AppendToOutgoingContext(ctx, scoring.TenantMetadataKey, "tenant-1")
+	response, err := client.GetScore(ctx, &scoring.ScoreRequest{DocumentNumber: "12345678900", DocumentType: "CPF"})
+	require.NoError(t, err)
+	assert.Equal(t, 1000, response.Score)
+	assert.Equal(t, scoring.SourceProvider, response.Source)
+
+	_, err = client.GetScore(context.Background(), &scoring.ScoreRequest{TenantID: "tenant-1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	primary.err = errors.New("indisponível")
+	_, err = client.GetScore(context.Background(), &scoring.ScoreRequest{TenantID: "tenant-1", DocumentNumber: "98765432100"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,458 @@
+/**
+ * @file monitor.go
+ * @description Monitorização de SLA dos provedores de crédito com degradação automática
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package sla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// Tier define o nível de prioridade de um provedor na orquestração
+type Tier string
+
+const (
+	TierPrimary  Tier = "PRIMARY"
+	TierDegraded Tier = "DEGRADED" // Consultado apenas depois dos provedores primários
+)
+
+// Tipos de evento publicados nas mudanças de nível
+const (
+	EventSLABreach    = "PROVIDER_SLA_BREACH"
+	EventSLARecovered = "PROVIDER_SLA_RECOVERED"
+)
+
+// Motivos de violação do SLO
+const (
+	ReasonAvailability = "AVAILABILITY"
+	ReasonLatency      = "LATENCY_P95"
+)
+
+// Valores padrão da monitorização
+const (
+	DefaultWindow          = 5 * time.Minute
+	DefaultMinSamples      = 20
+	DefaultRecoveryPeriod  = 2 * time.Minute
+	DefaultMinAvailability = 0.99
+	DefaultMaxP95Latency   = 2 * time.Second
+)
+
+// SLO define os objetivos de nível de serviço de um provedor
+type SLO struct {
+	// MinAvailability é a fração mínima de consultas bem-sucedidas na janela (0 a 1)
+	MinAvailability float64 `json:"minAvailability"`
+
+	// MaxP95Latency é a latência máxima admitida para o percentil 95
+	MaxP95Latency time.Duration `json:"maxP95Latency"`
+}
+
+// Config define a monitorização de SLA dos provedores
+type Config struct {
+	// Window é a janela deslizante usada no cálculo da disponibilidade e da latência
+	Window time.Duration `json:"window"`
+
+	// MinSamples é o número mínimo de consultas na janela para avaliar o SLO
+	MinSamples int `json:"minSamples"`
+
+	// RecoveryPeriod é o tempo mínimo em degradação antes de o provedor poder recuperar
+	RecoveryPeriod time.Duration `json:"recoveryPeriod"`
+
+	// DefaultSLO aplica-se aos provedores sem SLO próprio
+	DefaultSLO SLO `json:"defaultSlo"`
+
+	// ProviderSLOs sobrepõe DefaultSLO por provedor
+	ProviderSLOs map[string]SLO `json:"providerSlos,omitempty"`
+}
+
+// DefaultConfig retorna a configuração padrão da monitorização
+func DefaultConfig() Config {
+	return Config{
+		Window:         DefaultWindow,
+		MinSamples:     DefaultMinSamples,
+		RecoveryPeriod: DefaultRecoveryPeriod,
+		DefaultSLO: SLO{
+			MinAvailability: DefaultMinAvailability,
+			MaxP95Latency:   DefaultMaxP95Latency,
+		},
+	}
+}
+
+// sloFor retorna o SLO do provedor, recorrendo ao SLO padrão
+func (c Config) sloFor(provider string) SLO {
+	if slo, exists := c.ProviderSLOs[provider]; exists {
+		return slo
+	}
+	return c.DefaultSLO
+}
+
+// ProviderStatus descreve o cumprimento do SLO por um provedor
+type ProviderStatus struct {
+	Provider      string     `json:"provider"`
+	Tier          Tier       `json:"tier"`
+	SLO           SLO        `json:"slo"`
+	Samples       int        `json:"samples"`
+	Failures      int        `json:"failures"`
+	Availability  float64    `json:"availability"`
+	P95LatencyMs  int64      `json:"p95LatencyMs"`
+	Breaches      []string   `json:"breaches,omitempty"`
+	DegradedSince *time.Time `json:"degradedSince,omitempty"`
+	EvaluatedAt   time.Time  `json:"evaluatedAt"`
+}
+
+// Event é o evento de operações publicado quando um provedor muda de nível
+type Event struct {
+	EventType       string    `json:"eventType"`
+	Severity        string    `json:"severity"`
+	Provider        string    `json:"provider"`
+	Tier            Tier      `json:"tier"`
+	Reasons         []string  `json:"reasons,omitempty"`
+	Availability    float64   `json:"availability"`
+	P95LatencyMs    int64     `json:"p95LatencyMs"`
+	MinAvailability float64   `json:"minAvailability"`
+	MaxP95LatencyMs int64     `json:"maxP95LatencyMs"`
+	Samples         int       `json:"samples"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// EventPublisher publica os eventos de violação e recuperação de SLA
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, event interface{}) error
+}
+
+// sample é o resultado de uma consulta a um provedor
+type sample struct {
+	at      time.Time
+	latency time.Duration
+	success bool
+}
+
+// providerState contém as amostras e o nível corrente de um provedor
+type providerState struct {
+	samples       []sample
+	tier          Tier
+	degradedSince time.Time
+}
+
+// Monitor acompanha a disponibilidade e a latência p95 de cada provedor na janela configurada
+// e move para o nível degradado os provedores que violam o SLO
+type Monitor struct {
+	config    Config
+	publisher EventPublisher
+	metrics   *Metrics
+	now       func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]*providerState
+}
+
+// NewMonitor cria um monitor de SLA; o publicador de eventos é opcional
+func NewMonitor(config Config, publisher EventPublisher) *Monitor {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = DefaultMinSamples
+	}
+	if config.RecoveryPeriod < 0 {
+		config.RecoveryPeriod = 0
+	}
+	return &Monitor{
+		config:    config,
+		publisher: publisher,
+		now:       time.Now,
+		providers: make(map[string]*providerState),
+	}
+}
+
+// SetClock substitui o relógio usado na janela deslizante
+func (m *Monitor) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// SetMetrics define as métricas Prometheus atualizadas a cada avaliação
+func (m *Monitor) SetMetrics(metrics *Metrics) {
+	m.metrics = metrics
+}
+
+// Record regista o resultado de uma consulta e reavalia o nível do provedor
+// Consultas canceladas pelo chamador (ex.: a perdedora de um pedido hedged) não contam para o SLO
+func (m *Monitor) Record(provider string, latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	now := m.now()
+
+	m.mu.Lock()
+	state := m.state(provider)
+	state.samples = append(state.samples, sample{at: now, latency: latency, success: err == nil})
+	status, event := m.evaluate(provider, state, now)
+	m.mu.Unlock()
+
+	m.metrics.observe(status, event)
+	if event != nil {
+		m.publish(*event)
+	}
+}
+
+// Status retorna o cumprimento do SLO pelo provedor
+func (m *Monitor) Status(provider string) ProviderStatus {
+	now := m.now()
+
+	m.mu.Lock()
+	state, exists := m.providers[provider]
+	if !exists {
+		// Provedores sem consultas registadas não são memorizados
+		state = &providerState{tier: TierPrimary}
+	}
+	status, event := m.evaluate(provider, state, now)
+	m.mu.Unlock()
+
+	m.metrics.observe(status, event)
+	if event != nil {
+		m.publish(*event)
+	}
+	return status
+}
+
+// Statuses retorna o estado de todos os provedores monitorizados, ordenados por nome
+func (m *Monitor) Statuses() []ProviderStatus {
+	m.mu.Lock()
+	providers := make([]string, 0, len(m.providers))
+	for provider := range m.providers {
+		providers = append(providers, provider)
+	}
+	m.mu.Unlock()
+
+	sort.Strings(providers)
+	statuses := make([]ProviderStatus, 0, len(providers))
+	for _, provider := range providers {
+		statuses = append(statuses, m.Status(provider))
+	}
+	return statuses
+}
+
+// Prioritize reordena os provedores colocando os degradados no fim,
+// preservando a ordem de preferência dentro de cada nível
+func (m *Monitor) Prioritize(providers []string) []string {
+	if m == nil || len(providers) < 2 {
+		return providers
+	}
+
+	primary := make([]string, 0, len(providers))
+	var degraded []string
+	for _, provider := range providers {
+		if m.Status(provider).Tier == TierDegraded {
+			degraded = append(degraded, provider)
+			continue
+		}
+		primary = append(primary, provider)
+	}
+	return append(primary, degraded...)
+}
+
+// state retorna o estado do provedor, criando-o no primeiro uso
+func (m *Monitor) state(provider string) *providerState {
+	state, exists := m.providers[provider]
+	if !exists {
+		state = &providerState{tier: TierPrimary}
+		m.providers[provider] = state
+	}
+	return state
+}
+
+// evaluate descarta as amostras fora da janela, calcula os indicadores e aplica a transição de nível
+// Sem amostras suficientes o provedor mantém o nível; a recuperação exige cumprir o SLO
+// depois de decorrido o período mínimo em degradação
+func (m *Monitor) evaluate(provider string, state *providerState, now time.Time) (ProviderStatus, *Event) {
+	cutoff := now.Add(-m.config.Window)
+	kept := state.samples[:0]
+	for _, s := range state.samples {
+		if s.at.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	state.samples = kept
+
+	slo := m.config.sloFor(provider)
+	status := ProviderStatus{
+		Provider:     provider,
+		SLO:          slo,
+		Samples:      len(kept),
+		Availability: 1,
+		EvaluatedAt:  now,
+	}
+
+	latencies := make([]time.Duration, 0, len(kept))
+	for _, s := range kept {
+		if !s.success {
+			status.Failures++
+			continue
+		}
+		latencies = append(latencies, s.latency)
+	}
+	if status.Samples > 0 {
+		status.Availability = float64(status.Samples-status.Failures) / float64(status.Samples)
+	}
+	p95 := percentile(latencies, 0.95)
+	status.P95LatencyMs = p95.Milliseconds()
+
+	if status.Samples >= m.config.MinSamples {
+		if slo.MinAvailability > 0 && status.Availability < slo.MinAvailability {
+			status.Breaches = append(status.Breaches, ReasonAvailability)
+		}
+		if slo.MaxP95Latency > 0 && p95 > slo.MaxP95Latency {
+			status.Breaches = append(status.Breaches, ReasonLatency)
+		}
+	}
+
+	var event *Event
+	switch {
+	case state.tier == TierPrimary && len(status.Breaches) > 0:
+		state.tier = TierDegraded
+		state.degradedSince = now
+		event = newEvent(EventSLABreach, "WARNING", status, now)
+	case state.tier == TierDegraded && len(status.Breaches) == 0 &&
+		status.Samples >= m.config.MinSamples && now.Sub(state.degradedSince) >= m.config.RecoveryPeriod:
+		state.tier = TierPrimary
+		state.degradedSince = time.Time{}
+		event = newEvent(EventSLARecovered, "INFO", status, now)
+	}
+
+	status.Tier = state.tier
+	if state.tier == TierDegraded {
+		since := state.degradedSince
+		status.DegradedSince = &since
+	}
+	if event != nil {
+		event.Tier = state.tier
+	}
+	return status, event
+}
+
+// publish envia o evento de forma assíncrona e regista a transição
+func (m *Monitor) publish(event Event) {
+	logEvent := log.Warn()
+	if event.EventType == EventSLARecovered {
+		logEvent = log.Info()
+	}
+	logEvent.
+		Str("provider", event.Provider).
+		Str("tier", string(event.Tier)).
+		Strs("reasons", event.Reasons).
+		Float64("availability", event.Availability).
+		Int64("p95_latency_ms", event.P95LatencyMs).
+		Msg(fmt.Sprintf("Provedor de crédito %s: %s", event.Provider, event.EventType))
+
+	if m.publisher == nil {
+		return
+	}
+	go func() {
+		if err := m.publisher.PublishEvent(context.Background(), event); err != nil {
+			log.Error().Err(err).Str("provider", event.Provider).Msg("Falha ao publicar evento de SLA do provedor")
+		}
+	}()
+}
+
+// newEvent cria o evento de mudança de nível a partir do estado avaliado
+func newEvent(eventType, severity string, status ProviderStatus, now time.Time) *Event {
+	return &Event{
+		EventType:       eventType,
+		Severity:        severity,
+		Provider:        status.Provider,
+		Reasons:         status.Breaches,
+		Availability:    status.Availability,
+		P95LatencyMs:    status.P95LatencyMs,
+		MinAvailability: status.SLO.MinAvailability,
+		MaxP95LatencyMs: status.SLO.MaxP95Latency.Milliseconds(),
+		Samples:         status.Samples,
+		Timestamp:       now,
+	}
+}
+
+// percentile calcula o percentil pelo método nearest-rank
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Metrics contém as métricas Prometheus do cumprimento de SLA
+type Metrics struct {
+	availabilityGauge *prometheus.GaugeVec
+	latencyGauge      *prometheus.GaugeVec
+	degradedGauge     *prometheus.GaugeVec
+	breachesCounter   *prometheus.CounterVec
+}
+
+// NewMetrics cria e regista as métricas de SLA dos provedores
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		availabilityGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_provider_availability_ratio",
+				Help: "Disponibilidade do provedor de crédito na janela de SLA",
+			},
+			[]string{"provider"},
+		),
+		latencyGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_provider_p95_latency_seconds",
+				Help: "Latência p95 das consultas bem-sucedidas na janela de SLA",
+			},
+			[]string{"provider"},
+		),
+		degradedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "bureau_credito_provider_degraded",
+				Help: "Indica (1) se o provedor está no nível degradado da orquestração",
+			},
+			[]string{"provider"},
+		),
+		breachesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "bureau_credito_provider_sla_breaches_total",
+				Help: "Número total de degradações de provedores por violação de SLO",
+			},
+			[]string{"provider"},
+		),
+	}
+
+	registry.MustRegister(m.availabilityGauge, m.latencyGauge, m.degradedGauge, m.breachesCounter)
+	return m
+}
+
+// observe atualiza as métricas com o estado avaliado
+func (m *Metrics) observe(status ProviderStatus, event *Event) {
+	if m == nil {
+		return
+	}
+	m.availabilityGauge.WithLabelValues(status.Provider).Set(status.Availability)
+	m.latencyGauge.WithLabelValues(status.Provider).Set(float64(status.P95LatencyMs) / 1000)
+	degraded := 0.0
+	if status.Tier == TierDegraded {
+		degraded = 1
+	}
+	m.degradedGauge.WithLabelValues(status.Provider).Set(degraded)
+	if event != nil && event.EventType == EventSLABreach {
+		m.breachesCounter.WithLabelValues(status.Provider).Inc()
+	}
+}
@@ -0,0 +1,198 @@
+/**
+ * @file monitor_test.go
+ * @description Testes da monitorização de SLA e da degradação automática dos provedores
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/bureau-credito/sla"
+)
+
+// testClock é um relógio ajustável para os testes da janela deslizante
+type testClock struct {
+	now   time.Time
+	mutex sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// eventRecorder guarda os eventos publicados pelo monitor
+type eventRecorder struct {
+	events chan sla.Event
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{events: make(chan sla.Event, 16)}
+}
+
+func (r *eventRecorder) PublishEvent(ctx context.Context, event interface{}) error {
+	r.events <- event.(sla.Event)
+	return nil
+}
+
+func (r *eventRecorder) next(t *testing.T) sla.Event {
+	t.Helper()
+	select {
+	case event := <-r.events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("evento de SLA não publicado")
+		return sla.Event{}
+	}
+}
+
+func newTestMonitor(recorder *eventRecorder) (*sla.Monitor, *testClock) {
+	config := sla.DefaultConfig()
+	config.MinSamples = 10
+	config.RecoveryPeriod = 10 * time.Minute
+	config.ProviderSLOs = map[string]sla.SLO{
+		"bureau-lento": {MinAvailability: 0.9, MaxP95Latency: 500 * time.Millisecond},
+	}
+
+	clock := &testClock{now: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)}
+	var publisher sla.EventPublisher
+	if recorder != nil {
+		publisher = recorder
+	}
+	monitor := sla.NewMonitor(config, publisher)
+	monitor.SetClock(clock.Now)
+	return monitor, clock
+}
+
+func recordN(monitor *sla.Monitor, provider string, n int, latency time.Duration, err error) {
+	for i := 0; i < n; i++ {
+		monitor.Record(provider, latency, err)
+	}
+}
+
+func TestMonitorDegradesOnAvailabilityBreach(t *testing.T) {
+	recorder := newEventRecorder()
+	monitor, _ := newTestMonitor(recorder)
+
+	recordN(monitor, "bureau-a", 9, 100*time.Millisecond, nil)
+	monitor.Record("bureau-a", 0, errors.New("timeout"))
+
+	status := monitor.Status("bureau-a")
+	assert.Equal(t, sla.TierDegraded, status.Tier)
+	assert.Equal(t, 10, status.Samples)
+	assert.Equal(t, 1, status.Failures)
+	assert.InDelta(t, 0.9, status.Availability, 0.0001)
+	assert.Equal(t, []string{sla.ReasonAvailability}, status.Breaches)
+	require.NotNil(t, status.DegradedSince)
+
+	event := recorder.next(t)
+	assert.Equal(t, sla.EventSLABreach, event.EventType)
+	assert.Equal(t, "bureau-a", event.Provider)
+	assert.Equal(t, sla.TierDegraded, event.Tier)
+	assert.Equal(t, []string{sla.ReasonAvailability}, event.Reasons)
+}
+
+func TestMonitorDegradesOnLatencyBreachWithProviderSLO(t *testing.T) {
+	recorder := newEventRecorder()
+	monitor, _ := newTestMonitor(recorder)
+
+	recordN(monitor, "bureau-lento", 9, 200*time.Millisecond, nil)
+	monitor.Record("bureau-lento", 800*time.Millisecond, nil)
+
+	status := monitor.Status("bureau-lento")
+	assert.Equal(t, sla.TierDegraded, status.Tier)
+	assert.Equal(t, int64(800), status.P95LatencyMs)
+	assert.Equal(t, []string{sla.ReasonLatency}, status.Breaches)
+
+	// O mesmo perfil de latência cumpre o SLO padrão
+	recordN(monitor, "bureau-a", 9, 200*time.Millisecond, nil)
+	monitor.Record("bureau-a", 800*time.Millisecond, nil)
+	assert.Equal(t, sla.TierPrimary, monitor.Status("bureau-a").Tier)
+
+	assert.Equal(t, sla.EventSLABreach, recorder.next(t).EventType)
+}
+
+func TestMonitorRequiresMinimumSamples(t *testing.T) {
+	monitor, _ := newTestMonitor(nil)
+
+	recordN(monitor, "bureau-a", 5, 100*time.Millisecond, errors.New("indisponível"))
+
+	status := monitor.Status("bureau-a")
+	assert.Equal(t, sla.TierPrimary, status.Tier)
+	assert.Equal(t, 0.0, status.Availability)
+	assert.Empty(t, status.Breaches)
+}
+
+func TestMonitorIgnoresCanceledRequests(t *testing.T) {
+	monitor, _ := newTestMonitor(nil)
+
+	recordN(monitor, "bureau-a", 20, 3*time.Second, context.Canceled)
+
+	status := monitor.Status("bureau-a")
+	assert.Equal(t, 0, status.Samples)
+	assert.Equal(t, sla.TierPrimary, status.Tier)
+}
+
+func TestMonitorRecoversAfterRecoveryPeriod(t *testing.T) {
+	recorder := newEventRecorder()
+	monitor, clock := newTestMonitor(recorder)
+
+	recordN(monitor, "bureau-a", 10, 100*time.Millisecond, errors.New("timeout"))
+	require.Equal(t, sla.TierDegraded, monitor.Status("bureau-a").Tier)
+	assert.Equal(t, sla.EventSLABreach, recorder.next(t).EventType)
+
+	// As falhas saem da janela, mas o período mínimo em degradação ainda não decorreu
+	clock.Advance(6 * time.Minute)
+	recordN(monitor, "bureau-a", 10, 100*time.Millisecond, nil)
+	assert.Equal(t, sla.TierDegraded, monitor.Status("bureau-a").Tier)
+
+	clock.Advance(4 * time.Minute)
+	status := monitor.Status("bureau-a")
+	assert.Equal(t, sla.TierPrimary, status.Tier)
+	assert.Nil(t, status.DegradedSince)
+	assert.Equal(t, 1.0, status.Availability)
+
+	event := recorder.next(t)
+	assert.Equal(t, sla.EventSLARecovered, event.EventType)
+	assert.Equal(t, sla.TierPrimary, event.Tier)
+}
+
+func TestMonitorPrioritizeMovesDegradedProvidersLast(t *testing.T) {
+	monitor, _ := newTestMonitor(nil)
+
+	recordN(monitor, "bureau-a", 10, 100*time.Millisecond, errors.New("timeout"))
+	recordN(monitor, "bureau-b", 10, 100*time.Millisecond, nil)
+
+	ordered := monitor.Prioritize([]string{"bureau-a", "bureau-b", "bureau-c"})
+	assert.Equal(t, []string{"bureau-b", "bureau-c", "bureau-a"}, ordered)
+}
+
+func TestMonitorStatusesSortedByProvider(t *testing.T) {
+	monitor, _ := newTestMonitor(nil)
+
+	monitor.Record("bureau-b", 100*time.Millisecond, nil)
+	monitor.Record("bureau-a", 100*time.Millisecond, nil)
+
+	statuses := monitor.Statuses()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "bureau-a", statuses[0].Provider)
+	assert.Equal(t, "bureau-b", statuses[1].Provider)
+	assert.Equal(t, sla.DefaultMaxP95Latency, statuses[0].SLO.MaxP95Latency)
+}
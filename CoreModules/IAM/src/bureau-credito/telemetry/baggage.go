@@ -0,0 +1,185 @@
+/**
+ * @file baggage.go
+ * @description Propagação da correlação de consultas via baggage OpenTelemetry
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Chaves de baggage (e de atributos de span) da correlação de consultas
+const (
+	BaggageConsultaID = "consulta.id"
+	BaggageTenantID   = "tenant.id"
+	BaggageMarket     = "market"
+)
+
+// TracerName é o nome do tracer dos spans de provedores do Bureau de Crédito
+const TracerName = "innovabiz.iam.bureau-credito"
+
+// propagator propaga o contexto de trace e o baggage nos cabeçalhos HTTP dos provedores
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// ConsultaContext identifica a consulta a que pertencem os spans e as chamadas a provedores
+type ConsultaContext struct {
+	ConsultaID string
+	TenantID   string
+	Market     string
+}
+
+// WithConsulta adiciona a identificação da consulta ao baggage do contexto, preservando
+// os restantes membros; campos vazios não são propagados
+func WithConsulta(ctx context.Context, consulta ConsultaContext) context.Context {
+	bag := baggage.FromContext(ctx)
+	for key, value := range consulta.values() {
+		if value == "" {
+			continue
+		}
+		member, err := baggage.NewMember(key, url.PathEscape(value))
+		if err != nil {
+			continue
+		}
+		if updated, err := bag.SetMember(member); err == nil {
+			bag = updated
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// ConsultaFromContext extrai a identificação da consulta do baggage do contexto
+func ConsultaFromContext(ctx context.Context) ConsultaContext {
+	bag := baggage.FromContext(ctx)
+	return ConsultaContext{
+		ConsultaID: bag.Member(BaggageConsultaID).Value(),
+		TenantID:   bag.Member(BaggageTenantID).Value(),
+		Market:     bag.Member(BaggageMarket).Value(),
+	}
+}
+
+// Attributes retorna os atributos de span da consulta, omitindo os campos vazios
+func (c ConsultaContext) Attributes() []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 3)
+	if c.ConsultaID != "" {
+		attrs = append(attrs, attribute.String(BaggageConsultaID, c.ConsultaID))
+	}
+	if c.TenantID != "" {
+		attrs = append(attrs, attribute.String(BaggageTenantID, c.TenantID))
+	}
+	if c.Market != "" {
+		attrs = append(attrs, attribute.String(BaggageMarket, c.Market))
+	}
+	return attrs
+}
+
+// values associa cada chave de baggage ao respetivo campo
+func (c ConsultaContext) values() map[string]string {
+	return map[string]string{
+		BaggageConsultaID: c.ConsultaID,
+		BaggageTenantID:   c.TenantID,
+		BaggageMarket:     c.Market,
+	}
+}
+
+// BaggageSpanProcessor copia a identificação da consulta do baggage para todos os spans
+// iniciados, permitindo pesquisar os traces pela consulta sem instrumentação adicional.
+// Deve ser registado no TracerProvider com sdktrace.WithSpanProcessor.
+type BaggageSpanProcessor struct{}
+
+// NewBaggageSpanProcessor cria o processador de enriquecimento de spans
+func NewBaggageSpanProcessor() *BaggageSpanProcessor {
+	return &BaggageSpanProcessor{}
+}
+
+// OnStart enriquece o span com os atributos da consulta presentes no contexto pai
+func (p *BaggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if attrs := ConsultaFromContext(parent).Attributes(); len(attrs) > 0 {
+		s.SetAttributes(attrs...)
+	}
+}
+
+// OnEnd não faz nada; o enriquecimento ocorre no início do span
+func (p *BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown não faz nada; o processador não mantém estado
+func (p *BaggageSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush não faz nada; o processador não mantém estado
+func (p *BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// StartProviderSpan inicia o span de uma consulta a um provedor de crédito
+func StartProviderSpan(ctx context.Context, provider string) (context.Context, trace.Span) {
+	attrs := append(ConsultaFromContext(ctx).Attributes(), attribute.String("provider.id", provider))
+	return otel.Tracer(TracerName).Start(ctx, "bureau_credito.provider_consulta",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// EndSpan regista o erro (se houver) e encerra o span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport instrumenta as chamadas HTTP aos provedores: cria um span de cliente
+// enriquecido com a consulta e propaga o trace e o baggage nos cabeçalhos
+type Transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport cria um transporte instrumentado sobre base (http.DefaultTransport se nil)
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base}
+}
+
+// RoundTrip executa a requisição HTTP dentro de um span de cliente
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := append(ConsultaFromContext(req.Context()).Attributes(),
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL.Redacted()),
+		attribute.String("net.peer.name", req.URL.Hostname()),
+	)
+	ctx, span := otel.Tracer(TracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	// A requisição não deve ser alterada pelo RoundTripper; os cabeçalhos vão num clone
+	req = req.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, "status HTTP "+strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,139 @@
+/**
+ * @file baggage_test.go
+ * @description Testes para a propagação da correlação de consultas via baggage
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"innovabiz/iam/src/bureau-credito/telemetry"
+)
+
+// newRecorder instala um TracerProvider com o processador de baggage e um gravador de spans
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(telemetry.NewBaggageSpanProcessor()),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// attributesOf converte os atributos de um span num mapa
+func attributesOf(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+// TestWithConsulta_RoundTrip testa a gravação e leitura da consulta no baggage
+func TestWithConsulta_RoundTrip(t *testing.T) {
+	existing, err := baggage.NewMember("session.id", "abc")
+	require.NoError(t, err)
+	bag, err := baggage.New(existing)
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	ctx = telemetry.WithConsulta(ctx, telemetry.ConsultaContext{
+		ConsultaID: "consulta-1",
+		TenantID:   "banco x",
+		Market:     "brazil",
+	})
+
+	consulta := telemetry.ConsultaFromContext(ctx)
+	assert.Equal(t, "consulta-1", consulta.ConsultaID)
+	assert.Equal(t, "banco x", consulta.TenantID, "valores com espaços devem sobreviver à codificação")
+	assert.Equal(t, "brazil", consulta.Market)
+	assert.Equal(t, "abc", baggage.FromContext(ctx).Member("session.id").Value(), "membros existentes são preservados")
+
+	// Campos vazios não são propagados
+	ctx = telemetry.WithConsulta(context.Background(), telemetry.ConsultaContext{ConsultaID: "consulta-2"})
+	assert.Equal(t, 1, baggage.FromContext(ctx).Len())
+	assert.Len(t, telemetry.ConsultaFromContext(ctx).Attributes(), 1)
+}
+
+// TestBaggageSpanProcessor_EnrichesSpans testa o enriquecimento automático dos spans
+func TestBaggageSpanProcessor_EnrichesSpans(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx := telemetry.WithConsulta(context.Background(), telemetry.ConsultaContext{
+		ConsultaID: "consulta-1",
+		TenantID:   "tenant-1",
+		Market:     "angola",
+	})
+
+	_, span := otel.Tracer("test").Start(ctx, "operacao")
+	span.End()
+	_, providerSpan := telemetry.StartProviderSpan(ctx, "serasa")
+	telemetry.EndSpan(providerSpan, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	for _, s := range spans {
+		attrs := attributesOf(s)
+		assert.Equal(t, "consulta-1", attrs[telemetry.BaggageConsultaID], s.Name())
+		assert.Equal(t, "tenant-1", attrs[telemetry.BaggageTenantID], s.Name())
+		assert.Equal(t, "angola", attrs[telemetry.BaggageMarket], s.Name())
+	}
+	assert.Equal(t, "serasa", attributesOf(spans[1])["provider.id"])
+}
+
+// TestTransport_PropagatesBaggage testa a propagação do trace e do baggage nos cabeçalhos HTTP
+func TestTransport_PropagatesBaggage(t *testing.T) {
+	recorder := newRecorder(t)
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx := telemetry.WithConsulta(context.Background(), telemetry.ConsultaContext{
+		ConsultaID: "consulta-1",
+		TenantID:   "tenant-1",
+	})
+	ctx, parent := telemetry.StartProviderSpan(ctx, "serasa")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/credit-report", nil)
+	require.NoError(t, err)
+	client := &http.Client{Transport: telemetry.NewTransport(nil)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	telemetry.EndSpan(parent, nil)
+
+	assert.Empty(t, req.Header.Get("traceparent"), "a requisição original não deve ser alterada")
+	assert.Contains(t, received.Get("baggage"), "consulta.id=consulta-1")
+	assert.Contains(t, received.Get("baggage"), "tenant.id=tenant-1")
+	require.NotEmpty(t, received.Get("traceparent"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	clientSpan := spans[0]
+	assert.Equal(t, "HTTP POST", clientSpan.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), clientSpan.Parent().SpanID())
+	assert.Contains(t, received.Get("traceparent"), clientSpan.SpanContext().SpanID().String())
+	assert.Equal(t, "503", attributesOf(clientSpan)["http.status_code"])
+	assert.Equal(t, "Error", clientSpan.Status().Code.String())
+}
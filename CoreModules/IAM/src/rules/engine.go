@@ -0,0 +1,333 @@
+/**
+ * @file engine.go
+ * @description Motor de regras por mercado partilhado pelo gateway de pagamentos e pelo Bureau de Crédito
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MarketGlobal identifica as regras aplicáveis a todos os mercados (mesmo valor de constants.MarketGlobal)
+const MarketGlobal = "Global"
+
+// SourceGo identifica as decisões tomadas pela validação em Go da própria regra
+const SourceGo = "go"
+
+// Resultado da avaliação de uma regra
+const (
+	ResultPassed = "passed" // Regra cumprida
+	ResultFailed = "failed" // Regra não cumprida
+	ResultError  = "error"  // Falha ao avaliar a regra
+)
+
+var (
+	// ErrInvalidRule indica uma regra sem identificador ou sem avaliação
+	ErrInvalidRule = errors.New("regra inválida")
+
+	// ErrDuplicateRule indica uma regra já registada com o mesmo identificador
+	ErrDuplicateRule = errors.New("regra já registada")
+)
+
+// Decision é a decisão de uma regra sobre um sujeito
+type Decision struct {
+	Passed  bool
+	Message string
+	Source  string // Origem da decisão (ex.: "go", "pdp"); vazio equivale a SourceGo
+}
+
+// Evaluator avalia uma regra sobre um sujeito
+type Evaluator[T any] func(ctx context.Context, subject T) (Decision, error)
+
+// Validator adapta as validações existentes (cumprida, mensagem, erro) a um Evaluator
+// Uma validação nil resulta num Evaluator nil, rejeitado por Register
+func Validator[T any](validate func(subject T) (bool, string, error)) Evaluator[T] {
+	if validate == nil {
+		return nil
+	}
+	return func(ctx context.Context, subject T) (Decision, error) {
+		passed, message, err := validate(subject)
+		return Decision{Passed: passed, Message: message, Source: SourceGo}, err
+	}
+}
+
+// Rule é uma regra de um mercado
+type Rule[T any] struct {
+	ID          string
+	Market      string // Mercado da regra; MarketGlobal aplica-a a todos
+	Description string
+	Framework   []string
+	Types       []string     // Tipos de operação a que a regra se aplica; vazio aplica-a a todos
+	Applies     func(T) bool // Filtro adicional sobre o sujeito (opcional)
+	Evaluate    Evaluator[T]
+}
+
+// appliesTo indica se a regra se aplica ao mercado, ao tipo de operação e ao sujeito
+func (r Rule[T]) appliesTo(market, operationType string, subject T) bool {
+	if r.Market != MarketGlobal && r.Market != market {
+		return false
+	}
+	if len(r.Types) > 0 && !contains(r.Types, operationType) {
+		return false
+	}
+	return r.Applies == nil || r.Applies(subject)
+}
+
+// Outcome é o resultado da avaliação de uma regra
+type Outcome struct {
+	RuleID string
+	Market string
+	Decision
+	Err      error
+	Duration time.Duration
+}
+
+// Result retorna o resultado da avaliação (ResultPassed, ResultFailed ou ResultError)
+func (o Outcome) Result() string {
+	switch {
+	case o.Err != nil:
+		return ResultError
+	case o.Passed:
+		return ResultPassed
+	default:
+		return ResultFailed
+	}
+}
+
+// Evaluation reúne os resultados das regras avaliadas, pela ordem de registo
+type Evaluation struct {
+	Outcomes []Outcome
+}
+
+// Failure retorna a primeira regra não cumprida ou com erro, ou nil quando todas foram cumpridas
+func (e Evaluation) Failure() *Outcome {
+	for i := range e.Outcomes {
+		if e.Outcomes[i].Result() != ResultPassed {
+			return &e.Outcomes[i]
+		}
+	}
+	return nil
+}
+
+// Passed indica se todas as regras aplicáveis foram cumpridas
+func (e Evaluation) Passed() bool {
+	return e.Failure() == nil
+}
+
+// Observer é notificado do resultado de cada regra avaliada
+type Observer[T any] func(ctx context.Context, subject T, outcome Outcome)
+
+// Engine regista as regras de um módulo e avalia as aplicáveis a cada operação
+type Engine[T any] struct {
+	name     string
+	tracer   trace.Tracer
+	metrics  *Metrics
+	observer Observer[T]
+
+	mu    sync.RWMutex
+	rules []Rule[T]
+	ids   map[string]struct{}
+}
+
+// NewEngine cria um motor de regras; o nome identifica o módulo nos spans e nas métricas
+func NewEngine[T any](name string) *Engine[T] {
+	return &Engine[T]{
+		name:   name,
+		tracer: otel.Tracer("innovabiz.rules"),
+		ids:    make(map[string]struct{}),
+	}
+}
+
+// SetTracer substitui o tracer usado nos spans de avaliação
+func (e *Engine[T]) SetTracer(tracer trace.Tracer) {
+	e.tracer = tracer
+}
+
+// SetMetrics define as métricas Prometheus das avaliações
+func (e *Engine[T]) SetMetrics(metrics *Metrics) {
+	e.metrics = metrics
+}
+
+// SetObserver define o observador notificado do resultado de cada regra
+func (e *Engine[T]) SetObserver(observer Observer[T]) {
+	e.observer = observer
+}
+
+// Register regista uma regra; as regras são avaliadas pela ordem de registo
+func (e *Engine[T]) Register(rule Rule[T]) error {
+	if rule.ID == "" || rule.Evaluate == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidRule, rule.ID)
+	}
+	if rule.Market == "" {
+		rule.Market = MarketGlobal
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.ids[rule.ID]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateRule, rule.ID)
+	}
+	e.ids[rule.ID] = struct{}{}
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// Rules retorna as regras registadas, pela ordem de registo
+func (e *Engine[T]) Rules() []Rule[T] {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	registered := make([]Rule[T], len(e.rules))
+	copy(registered, e.rules)
+	return registered
+}
+
+// Applicable retorna as regras aplicáveis ao mercado, ao tipo de operação e ao sujeito
+func (e *Engine[T]) Applicable(market, operationType string, subject T) []Rule[T] {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var applicable []Rule[T]
+	for _, rule := range e.rules {
+		if rule.appliesTo(market, operationType, subject) {
+			applicable = append(applicable, rule)
+		}
+	}
+	return applicable
+}
+
+// Evaluate avalia as regras aplicáveis pela ordem de registo, parando na primeira
+// regra não cumprida ou com erro
+func (e *Engine[T]) Evaluate(ctx context.Context, market, operationType string, subject T) Evaluation {
+	return e.evaluate(ctx, "rules.Evaluate", market, operationType, subject, true)
+}
+
+// EvaluateAll avalia todas as regras aplicáveis pela ordem de registo, parando apenas numa
+// regra com erro, para reportar todas as regras não cumpridas de uma operação
+func (e *Engine[T]) EvaluateAll(ctx context.Context, market, operationType string, subject T) Evaluation {
+	return e.evaluate(ctx, "rules.EvaluateAll", market, operationType, subject, false)
+}
+
+// evaluate avalia as regras aplicáveis; stopOnFailure interrompe a avaliação na primeira
+// regra não cumprida
+func (e *Engine[T]) evaluate(ctx context.Context, spanName, market, operationType string, subject T, stopOnFailure bool) Evaluation {
+	ctx, span := e.tracer.Start(ctx, spanName,
+		trace.WithAttributes(
+			attribute.String("rules.engine", e.name),
+			attribute.String("rules.market", market),
+			attribute.String("rules.operation_type", operationType),
+		),
+	)
+	defer span.End()
+
+	var evaluation Evaluation
+	for _, rule := range e.Applicable(market, operationType, subject) {
+		outcome := e.evaluateRule(ctx, rule, subject)
+		evaluation.Outcomes = append(evaluation.Outcomes, outcome)
+
+		span.AddEvent("rules.rule_evaluated", trace.WithAttributes(
+			attribute.String("rules.rule_id", rule.ID),
+			attribute.String("rules.source", outcome.Source),
+			attribute.String("rules.result", outcome.Result()),
+		))
+		if outcome.Result() == ResultPassed {
+			continue
+		}
+		if outcome.Err != nil {
+			span.RecordError(outcome.Err)
+		}
+		span.SetStatus(codes.Error, fmt.Sprintf("regra %s não cumprida", rule.ID))
+		if stopOnFailure || outcome.Err != nil {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.Int("rules.evaluated", len(evaluation.Outcomes)))
+	return evaluation
+}
+
+// evaluateRule avalia uma regra e regista o resultado nas métricas e no observador
+func (e *Engine[T]) evaluateRule(ctx context.Context, rule Rule[T], subject T) Outcome {
+	start := time.Now()
+	decision, err := rule.Evaluate(ctx, subject)
+	if decision.Source == "" {
+		decision.Source = SourceGo
+	}
+
+	outcome := Outcome{
+		RuleID:   rule.ID,
+		Market:   rule.Market,
+		Decision: decision,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+	e.metrics.observe(e.name, outcome)
+	if e.observer != nil {
+		e.observer(ctx, subject, outcome)
+	}
+	return outcome
+}
+
+// contains indica se o valor pertence à lista
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Metrics contém as métricas Prometheus das avaliações de regras
+// Uma instância pode ser partilhada por vários motores, distinguidos pelo rótulo engine
+type Metrics struct {
+	evaluationsCounter *prometheus.CounterVec
+	durationHistogram  *prometheus.HistogramVec
+}
+
+// NewMetrics cria e regista as métricas das avaliações de regras
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		evaluationsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "innovabiz_iam_rule_evaluations_total",
+				Help: "Número total de regras avaliadas pelo resultado (passed, failed, error)",
+			},
+			[]string{"engine", "market", "rule", "source", "result"},
+		),
+		durationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "innovabiz_iam_rule_evaluation_duration_seconds",
+				Help:    "Duração da avaliação de cada regra",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 12),
+			},
+			[]string{"engine", "rule", "source"},
+		),
+	}
+
+	registry.MustRegister(m.evaluationsCounter, m.durationHistogram)
+	return m
+}
+
+// observe regista o resultado e a duração da avaliação de uma regra
+func (m *Metrics) observe(engine string, outcome Outcome) {
+	if m == nil {
+		return
+	}
+	m.evaluationsCounter.WithLabelValues(engine, outcome.Market, outcome.RuleID, outcome.Source, outcome.Result()).Inc()
+	m.durationHistogram.WithLabelValues(engine, outcome.RuleID, outcome.Source).Observe(outcome.Duration.Seconds())
+}
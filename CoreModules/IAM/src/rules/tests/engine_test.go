@@ -0,0 +1,168 @@
+/**
+ * @file engine_test.go
+ * @description Testes do motor de regras por mercado
+ * @author InnovaBiz DevOps Team
+ * @copyright InnovaBiz 2025
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"innovabiz/iam/src/rules"
+)
+
+// operacao é o sujeito avaliado nos testes
+type operacao struct {
+	Entidade string
+}
+
+func passing() rules.Evaluator[*operacao] {
+	return rules.Validator(func(op *operacao) (bool, string, error) {
+		return true, "", nil
+	})
+}
+
+func newEngine(t *testing.T) *rules.Engine[*operacao] {
+	engine := rules.NewEngine[*operacao]("teste")
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{
+		ID: "global", Market: rules.MarketGlobal, Evaluate: passing(),
+	}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{
+		ID: "brasil_pix", Market: "Brasil", Types: []string{"pix"}, Evaluate: passing(),
+	}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{
+		ID: "angola_empresa", Market: "Angola", Evaluate: passing(),
+		Applies: func(op *operacao) bool { return op.Entidade == "empresa" },
+	}))
+	return engine
+}
+
+func ruleIDs(registered []rules.Rule[*operacao]) []string {
+	ids := make([]string, 0, len(registered))
+	for _, rule := range registered {
+		ids = append(ids, rule.ID)
+	}
+	return ids
+}
+
+func TestRegisterRejectsInvalidAndDuplicateRules(t *testing.T) {
+	engine := newEngine(t)
+
+	err := engine.Register(rules.Rule[*operacao]{ID: "sem_avaliacao"})
+	assert.ErrorIs(t, err, rules.ErrInvalidRule)
+
+	err = engine.Register(rules.Rule[*operacao]{ID: "sem_validacao", Evaluate: rules.Validator[*operacao](nil)})
+	assert.ErrorIs(t, err, rules.ErrInvalidRule)
+
+	err = engine.Register(rules.Rule[*operacao]{ID: "global", Evaluate: passing()})
+	assert.ErrorIs(t, err, rules.ErrDuplicateRule)
+
+	assert.Equal(t, []string{"global", "brasil_pix", "angola_empresa"}, ruleIDs(engine.Rules()))
+}
+
+func TestApplicableFiltersByMarketTypeAndSubject(t *testing.T) {
+	engine := newEngine(t)
+
+	assert.Equal(t, []string{"global", "brasil_pix"}, ruleIDs(engine.Applicable("Brasil", "pix", &operacao{})))
+	assert.Equal(t, []string{"global"}, ruleIDs(engine.Applicable("Brasil", "boleto", &operacao{})))
+	assert.Equal(t, []string{"global"}, ruleIDs(engine.Applicable("Angola", "pix", &operacao{Entidade: "pessoa"})))
+	assert.Equal(t, []string{"global", "angola_empresa"}, ruleIDs(engine.Applicable("Angola", "pix", &operacao{Entidade: "empresa"})))
+}
+
+func TestEvaluateStopsOnFirstFailure(t *testing.T) {
+	engine := rules.NewEngine[*operacao]("teste")
+	evaluated := []string{}
+	track := func(id string, passed bool) rules.Evaluator[*operacao] {
+		return func(ctx context.Context, op *operacao) (rules.Decision, error) {
+			evaluated = append(evaluated, id)
+			return rules.Decision{Passed: passed, Message: "limite excedido", Source: "pdp"}, nil
+		}
+	}
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "primeira", Evaluate: track("primeira", true)}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "limite", Evaluate: track("limite", false)}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "ultima", Evaluate: track("ultima", true)}))
+
+	evaluation := engine.Evaluate(context.Background(), "Brasil", "pix", &operacao{})
+
+	assert.Equal(t, []string{"primeira", "limite"}, evaluated)
+	assert.False(t, evaluation.Passed())
+	failure := evaluation.Failure()
+	require.NotNil(t, failure)
+	assert.Equal(t, "limite", failure.RuleID)
+	assert.Equal(t, rules.ResultFailed, failure.Result())
+	assert.Equal(t, "limite excedido", failure.Message)
+	assert.Equal(t, "pdp", failure.Source)
+}
+
+func TestEvaluateAllContinuesAfterFailureAndStopsOnError(t *testing.T) {
+	engine := rules.NewEngine[*operacao]("teste")
+	evaluated := []string{}
+	track := func(id string, passed bool, err error) rules.Evaluator[*operacao] {
+		return func(ctx context.Context, op *operacao) (rules.Decision, error) {
+			evaluated = append(evaluated, id)
+			return rules.Decision{Passed: passed, Message: id}, err
+		}
+	}
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "limite", Evaluate: track("limite", false, nil)}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "sancoes", Evaluate: track("sancoes", false, nil)}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "politica", Evaluate: track("politica", false, errors.New("PDP indisponível"))}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "ultima", Evaluate: track("ultima", true, nil)}))
+
+	evaluation := engine.EvaluateAll(context.Background(), "Brasil", "pix", &operacao{})
+
+	assert.Equal(t, []string{"limite", "sancoes", "politica"}, evaluated)
+	assert.False(t, evaluation.Passed())
+	failure := evaluation.Failure()
+	require.NotNil(t, failure)
+	assert.Equal(t, "limite", failure.RuleID, "Failure retorna a primeira regra não cumprida")
+	assert.Equal(t, rules.ResultError, evaluation.Outcomes[2].Result())
+	assert.Equal(t, rules.SourceGo, evaluation.Outcomes[0].Source)
+}
+
+func TestEvaluateReportsErrorsToObserverAndMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	engine := rules.NewEngine[*operacao]("teste")
+	engine.SetMetrics(rules.NewMetrics(registry))
+
+	var observed []string
+	engine.SetObserver(func(ctx context.Context, op *operacao, outcome rules.Outcome) {
+		observed = append(observed, outcome.RuleID+":"+outcome.Result())
+	})
+
+	errPolicy := errors.New("política indisponível")
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{ID: "global", Evaluate: passing()}))
+	require.NoError(t, engine.Register(rules.Rule[*operacao]{
+		ID: "brasil", Market: "Brasil",
+		Evaluate: rules.Validator(func(op *operacao) (bool, string, error) { return false, "", errPolicy }),
+	}))
+
+	evaluation := engine.Evaluate(context.Background(), "Brasil", "pix", &operacao{})
+
+	failure := evaluation.Failure()
+	require.NotNil(t, failure)
+	assert.ErrorIs(t, failure.Err, errPolicy)
+	assert.Equal(t, rules.ResultError, failure.Result())
+	assert.Equal(t, []string{"global:passed", "brasil:error"}, observed)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(registry, "innovabiz_iam_rule_evaluations_total"))
+}
+
+func TestEvaluateWithoutApplicableRulesPasses(t *testing.T) {
+	engine := newEngine(t)
+
+	evaluation := engine.Evaluate(context.Background(), "Moçambique", "mpesa", &operacao{})
+
+	assert.True(t, evaluation.Passed())
+	assert.Nil(t, evaluation.Failure())
+	require.Len(t, evaluation.Outcomes, 1)
+	assert.Equal(t, "global", evaluation.Outcomes[0].RuleID)
+}